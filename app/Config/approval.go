@@ -0,0 +1,98 @@
+package Config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 需要双人审批的敏感操作
+const (
+	ApprovalActionAlertRuleDelete = "alert_rule.delete"    // 删除告警规则
+	ApprovalActionBackupRestore   = "backup.restore"       // 从备份恢复数据
+	ApprovalActionAdminUnlock     = "account.unlock_admin" // 解除管理员账户的锁定
+	ApprovalActionSecurityConfig  = "security.config"      // 修改访问控制等安全配置
+)
+
+// ApprovalActions 支持审批的敏感操作
+var ApprovalActions = []string{
+	ApprovalActionAlertRuleDelete,
+	ApprovalActionBackupRestore,
+	ApprovalActionAdminUnlock,
+	ApprovalActionSecurityConfig,
+}
+
+// ApprovalConfig 敏感操作双人审批配置
+// 功能说明：
+// 1. Actions 中的操作由管理员发起时先创建待审批记录，需要另一名管理员在 TTL 内审批通过
+// 2. 审批通过后发起人在 ExecutionTTL 内携带审批ID重新提交相同的请求执行，每条审批只能执行一次
+// 3. 发起、审批、拒绝和执行都写入审计日志；Enabled 为false时所有操作直接执行
+type ApprovalConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`       // 是否启用双人审批
+	Actions      string        `mapstructure:"actions"`       // 需要审批的操作，逗号分隔
+	TTL          time.Duration `mapstructure:"ttl"`           // 待审批记录的有效期
+	ExecutionTTL time.Duration `mapstructure:"execution_ttl"` // 审批通过后执行的有效期
+}
+
+// SetDefaults 设置双人审批默认值
+func (c *ApprovalConfig) SetDefaults() {
+	viper.SetDefault("approval.enabled", false)
+	viper.SetDefault("approval.actions", strings.Join(ApprovalActions, ","))
+	viper.SetDefault("approval.ttl", "1h")
+	viper.SetDefault("approval.execution_ttl", "15m")
+}
+
+// BindEnvs 绑定双人审批环境变量
+func (c *ApprovalConfig) BindEnvs() {
+	viper.BindEnv("approval.enabled", "APPROVAL_ENABLED")
+	viper.BindEnv("approval.actions", "APPROVAL_ACTIONS")
+	viper.BindEnv("approval.ttl", "APPROVAL_TTL")
+	viper.BindEnv("approval.execution_ttl", "APPROVAL_EXECUTION_TTL")
+}
+
+// Validate 验证双人审批配置，未启用时不检查
+func (c *ApprovalConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.TTL <= 0 || c.ExecutionTTL <= 0 {
+		return fmt.Errorf("审批有效期必须大于0")
+	}
+	for _, action := range c.ActionList() {
+		if !validApprovalAction(action) {
+			return fmt.Errorf("不支持审批的操作: %s", action)
+		}
+	}
+	return nil
+}
+
+// ActionList 解析需要审批的操作
+func (c *ApprovalConfig) ActionList() []string {
+	var actions []string
+	for _, action := range strings.Split(c.Actions, ",") {
+		if action = strings.TrimSpace(action); action != "" {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
+// validApprovalAction 是否为支持审批的操作
+func validApprovalAction(action string) bool {
+	for _, item := range ApprovalActions {
+		if item == action {
+			return true
+		}
+	}
+	return false
+}
+
+// GetApprovalConfig 获取双人审批配置
+func GetApprovalConfig() *ApprovalConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.Approval
+}
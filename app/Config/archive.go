@@ -0,0 +1,141 @@
+package Config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 归档文件存储
+const (
+	ArchiveStoreLocal = "local"
+	ArchiveStoreS3    = "s3"
+)
+
+// ArchiveConfig 大表分区和归档配置
+// 功能说明：
+// 1. Tables 中的表按月分区：PostgreSQL使用原生范围分区，MySQL和SQLite按月轮转为独立的月表
+// 2. 分区的时间上界早于 HotRetention 时导出为gzip压缩的JSON Lines文件写入归档存储，然后删除分区，避免DELETE造成表膨胀
+// 3. 归档存储支持本地目录和S3兼容的对象存储（MinIO、阿里云OSS等）
+// 4. 查询按时间范围路由到在线表、月表和归档文件，合并后统一分页
+type ArchiveConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`        // 是否启用分区和归档
+	Tables        string        `mapstructure:"tables"`         // 分区的表，逗号分隔，支持 monitoring_metrics、login_attempts、security_events
+	HotRetention  time.Duration `mapstructure:"hot_retention"`  // 在线保留时间，更早的分区被归档
+	PremakeMonths int           `mapstructure:"premake_months"` // PostgreSQL提前创建的分区月数
+	CheckInterval time.Duration `mapstructure:"check_interval"` // 分区维护和归档任务的执行间隔
+	Store         string        `mapstructure:"store"`          // 归档存储：local、s3
+	LocalPath     string        `mapstructure:"local_path"`     // 本地归档目录
+	CacheSize     int           `mapstructure:"cache_size"`     // 查询时缓存的已解压归档文件数
+
+	// S3兼容对象存储
+	S3 struct {
+		Endpoint        string `mapstructure:"endpoint"` // 如 https://s3.amazonaws.com、http://minio:9000
+		Region          string `mapstructure:"region"`
+		Bucket          string `mapstructure:"bucket"`
+		AccessKeyID     string `mapstructure:"access_key_id"`
+		SecretAccessKey string `mapstructure:"secret_access_key"`
+		Prefix          string `mapstructure:"prefix"`     // 对象键前缀
+		PathStyle       bool   `mapstructure:"path_style"` // 使用路径形式访问存储桶（MinIO需要）
+	} `mapstructure:"s3"`
+}
+
+// SetDefaults 设置归档配置默认值
+func (a *ArchiveConfig) SetDefaults() {
+	viper.SetDefault("archive.enabled", false)
+	viper.SetDefault("archive.tables", "monitoring_metrics,login_attempts,security_events")
+	viper.SetDefault("archive.hot_retention", "2160h") // 90天
+	viper.SetDefault("archive.premake_months", 2)
+	viper.SetDefault("archive.check_interval", "6h")
+	viper.SetDefault("archive.store", ArchiveStoreLocal)
+	viper.SetDefault("archive.local_path", "storage/archive")
+	viper.SetDefault("archive.cache_size", 4)
+	viper.SetDefault("archive.s3.region", "us-east-1")
+	viper.SetDefault("archive.s3.prefix", "archive/")
+	viper.SetDefault("archive.s3.path_style", true)
+}
+
+// BindEnvs 绑定归档环境变量
+func (a *ArchiveConfig) BindEnvs() {
+	viper.BindEnv("archive.enabled", "ARCHIVE_ENABLED")
+	viper.BindEnv("archive.tables", "ARCHIVE_TABLES")
+	viper.BindEnv("archive.hot_retention", "ARCHIVE_HOT_RETENTION")
+	viper.BindEnv("archive.premake_months", "ARCHIVE_PREMAKE_MONTHS")
+	viper.BindEnv("archive.check_interval", "ARCHIVE_CHECK_INTERVAL")
+	viper.BindEnv("archive.store", "ARCHIVE_STORE")
+	viper.BindEnv("archive.local_path", "ARCHIVE_LOCAL_PATH")
+	viper.BindEnv("archive.cache_size", "ARCHIVE_CACHE_SIZE")
+	viper.BindEnv("archive.s3.endpoint", "ARCHIVE_S3_ENDPOINT")
+	viper.BindEnv("archive.s3.region", "ARCHIVE_S3_REGION")
+	viper.BindEnv("archive.s3.bucket", "ARCHIVE_S3_BUCKET")
+	viper.BindEnv("archive.s3.access_key_id", "ARCHIVE_S3_ACCESS_KEY_ID")
+	viper.BindEnv("archive.s3.secret_access_key", "ARCHIVE_S3_SECRET_ACCESS_KEY")
+	viper.BindEnv("archive.s3.prefix", "ARCHIVE_S3_PREFIX")
+	viper.BindEnv("archive.s3.path_style", "ARCHIVE_S3_PATH_STYLE")
+}
+
+// Validate 验证归档配置
+func (a *ArchiveConfig) Validate() error {
+	if !a.Enabled {
+		return nil
+	}
+	if len(a.TableNames()) == 0 {
+		return fmt.Errorf("归档表未配置")
+	}
+	if a.HotRetention < 24*time.Hour {
+		return fmt.Errorf("在线保留时间不能少于1天，当前值: %v", a.HotRetention)
+	}
+	if a.CheckInterval <= 0 {
+		return fmt.Errorf("归档检查间隔必须大于0")
+	}
+	if a.PremakeMonths < 0 {
+		return fmt.Errorf("提前创建的分区月数不能为负数")
+	}
+
+	switch a.StoreName() {
+	case ArchiveStoreLocal:
+		if a.LocalPath == "" {
+			return fmt.Errorf("本地归档目录未配置")
+		}
+	case ArchiveStoreS3:
+		if a.S3.Endpoint == "" || a.S3.Bucket == "" {
+			return fmt.Errorf("对象存储地址或存储桶未配置")
+		}
+		if a.S3.AccessKeyID == "" || a.S3.SecretAccessKey == "" {
+			return fmt.Errorf("对象存储访问密钥未配置")
+		}
+	default:
+		return fmt.Errorf("不支持的归档存储: %s", a.Store)
+	}
+	return nil
+}
+
+// TableNames 返回规范化后的分区表名
+func (a *ArchiveConfig) TableNames() []string {
+	names := make([]string, 0)
+	for _, name := range strings.Split(a.Tables, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// StoreName 获取规范化后的归档存储名称
+func (a *ArchiveConfig) StoreName() string {
+	store := strings.ToLower(strings.TrimSpace(a.Store))
+	if store == "" {
+		return ArchiveStoreLocal
+	}
+	return store
+}
+
+// GetArchiveConfig 获取归档配置
+func GetArchiveConfig() *ArchiveConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.Archive
+}
@@ -56,18 +56,51 @@ import (
 // - 支持配置版本管理
 // - 配置变更通知机制
 type Config struct {
-	Server            ServerConfig            `mapstructure:"server"`
-	Database          DatabaseConfig          `mapstructure:"database"`
-	JWT               JWTConfig               `mapstructure:"jwt"`
-	Redis             RedisConfig             `mapstructure:"redis"`
-	Storage           StorageConfig           `mapstructure:"storage"`
-	Email             EmailConfig             `mapstructure:"email"`
-	Log               LogConfig               `mapstructure:"log"`
-	Monitoring        MonitoringConfig        `mapstructure:"monitoring"`
-	WebSocket         WebSocketConfig         `mapstructure:"websocket"`
-	QueryOptimization QueryOptimizationConfig `mapstructure:"query_optimization"`
-	Security          SecurityConfig          `mapstructure:"security"`
-	Testing           TestConfig              `mapstructure:"testing"`
+	Server             ServerConfig             `mapstructure:"server"`
+	Database           DatabaseConfig           `mapstructure:"database"`
+	JWT                JWTConfig                `mapstructure:"jwt"`
+	Redis              RedisConfig              `mapstructure:"redis"`
+	Storage            StorageConfig            `mapstructure:"storage"`
+	Email              EmailConfig              `mapstructure:"email"`
+	Log                LogConfig                `mapstructure:"log"`
+	Monitoring         MonitoringConfig         `mapstructure:"monitoring"`
+	WebSocket          WebSocketConfig          `mapstructure:"websocket"`
+	QueryOptimization  QueryOptimizationConfig  `mapstructure:"query_optimization"`
+	Security           SecurityConfig           `mapstructure:"security"`
+	Testing            TestConfig               `mapstructure:"testing"`
+	I18n               I18nConfig               `mapstructure:"i18n"`
+	Search             SearchConfig             `mapstructure:"search"`
+	Resilience         ResilienceConfig         `mapstructure:"resilience"`
+	Notification       NotificationConfig       `mapstructure:"notification"`
+	SMS                SMSConfig                `mapstructure:"sms"`
+	Archive            ArchiveConfig            `mapstructure:"archive"`
+	Bulk               BulkConfig               `mapstructure:"bulk"`
+	Grpc               GrpcConfig               `mapstructure:"grpc"`
+	EventBus           EventBusConfig           `mapstructure:"event_bus"`
+	Webhooks           WebhookConfig            `mapstructure:"webhooks"`
+	OpenAPI            OpenAPIConfig            `mapstructure:"openapi"`
+	FaultInjection     FaultInjectionConfig     `mapstructure:"fault_injection"`
+	DebugMode          DebugModeConfig          `mapstructure:"debug_mode"`
+	Inspector          InspectorConfig          `mapstructure:"inspector"`
+	ModelCache         ModelCacheConfig         `mapstructure:"model_cache"`
+	Trash              TrashConfig              `mapstructure:"trash"`
+	Cleanup            CleanupConfig            `mapstructure:"cleanup"`
+	Impersonation      ImpersonationConfig      `mapstructure:"impersonation"`
+	Approval           ApprovalConfig           `mapstructure:"approval"`
+	UserSettings       UserSettingsConfig       `mapstructure:"user_settings"`
+	Teams              TeamsConfig              `mapstructure:"teams"`
+	Metering           MeteringConfig           `mapstructure:"metering"`
+	Billing            BillingConfig            `mapstructure:"billing"`
+	Export             ExportConfig             `mapstructure:"export"`
+	RequestSigning     RequestSigningConfig     `mapstructure:"request_signing"`
+	RequestTimeout     RequestTimeoutConfig     `mapstructure:"request_timeout"`
+	MiddlewarePipeline MiddlewarePipelineConfig `mapstructure:"middleware_pipeline"`
+	Gateway            GatewayConfig            `mapstructure:"gateway"`
+	Compression        CompressionConfig        `mapstructure:"compression"`
+	TLS                ServerTLSConfig          `mapstructure:"tls"`
+	Listeners          ServerListenersConfig    `mapstructure:"listeners"`
+	Startup            StartupConfig            `mapstructure:"startup"`
+	Migration          MigrationConfig          `mapstructure:"migration"`
 }
 
 var globalConfig *Config
@@ -86,6 +119,39 @@ func (c *Config) SetDefaults() {
 	c.QueryOptimization.SetDefaults()
 	c.Security.SetDefaults()
 	c.Testing.SetDefaults()
+	c.I18n.SetDefaults()
+	c.Search.SetDefaults()
+	c.Resilience.SetDefaults()
+	c.Notification.SetDefaults()
+	c.SMS.SetDefaults()
+	c.Archive.SetDefaults()
+	c.Bulk.SetDefaults()
+	c.Grpc.SetDefaults()
+	c.EventBus.SetDefaults()
+	c.Webhooks.SetDefaults()
+	c.OpenAPI.SetDefaults()
+	c.FaultInjection.SetDefaults()
+	c.DebugMode.SetDefaults()
+	c.Inspector.SetDefaults()
+	c.ModelCache.SetDefaults()
+	c.Trash.SetDefaults()
+	c.Cleanup.SetDefaults()
+	c.Impersonation.SetDefaults()
+	c.Approval.SetDefaults()
+	c.UserSettings.SetDefaults()
+	c.Teams.SetDefaults()
+	c.Metering.SetDefaults()
+	c.Billing.SetDefaults()
+	c.Export.SetDefaults()
+	c.RequestSigning.SetDefaults()
+	c.RequestTimeout.SetDefaults()
+	c.MiddlewarePipeline.SetDefaults()
+	c.Gateway.SetDefaults()
+	c.Compression.SetDefaults()
+	c.TLS.SetDefaults()
+	c.Listeners.SetDefaults()
+	c.Startup.SetDefaults()
+	c.Migration.SetDefaults()
 }
 
 // BindEnvs 绑定所有配置的环境变量
@@ -102,6 +168,39 @@ func (c *Config) BindEnvs() {
 	c.QueryOptimization.BindEnvs()
 	c.Security.BindEnvs()
 	c.Testing.BindEnvs()
+	c.I18n.BindEnvs()
+	c.Search.BindEnvs()
+	c.Resilience.BindEnvs()
+	c.Notification.BindEnvs()
+	c.SMS.BindEnvs()
+	c.Archive.BindEnvs()
+	c.Bulk.BindEnvs()
+	c.Grpc.BindEnvs()
+	c.EventBus.BindEnvs()
+	c.Webhooks.BindEnvs()
+	c.OpenAPI.BindEnvs()
+	c.FaultInjection.BindEnvs()
+	c.DebugMode.BindEnvs()
+	c.Inspector.BindEnvs()
+	c.ModelCache.BindEnvs()
+	c.Trash.BindEnvs()
+	c.Cleanup.BindEnvs()
+	c.Impersonation.BindEnvs()
+	c.Approval.BindEnvs()
+	c.UserSettings.BindEnvs()
+	c.Teams.BindEnvs()
+	c.Metering.BindEnvs()
+	c.Billing.BindEnvs()
+	c.Export.BindEnvs()
+	c.RequestSigning.BindEnvs()
+	c.RequestTimeout.BindEnvs()
+	c.MiddlewarePipeline.BindEnvs()
+	c.Gateway.BindEnvs()
+	c.Compression.BindEnvs()
+	c.TLS.BindEnvs()
+	c.Listeners.BindEnvs()
+	c.Startup.BindEnvs()
+	c.Migration.BindEnvs()
 }
 
 // LoadConfig 加载所有配置
@@ -258,7 +357,7 @@ func ValidateConfig() error {
 	}
 
 	// Redis配置验证：如果配置了Redis主机，则验证配置；否则跳过验证（Redis是可选的）
-	if globalConfig.Redis.Host != "" {
+	if globalConfig.Redis.IsConfigured() {
 		if err := globalConfig.Redis.Validate(); err != nil {
 			return fmt.Errorf("Redis配置验证失败: %v", err)
 		}
@@ -275,6 +374,142 @@ func ValidateConfig() error {
 		}
 	}
 
+	if err := globalConfig.I18n.Validate(); err != nil {
+		return fmt.Errorf("国际化配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.Search.Validate(); err != nil {
+		return fmt.Errorf("搜索配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.Resilience.Validate(); err != nil {
+		return fmt.Errorf("弹性保护配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.Notification.Validate(); err != nil {
+		return fmt.Errorf("站内通知配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.SMS.Validate(); err != nil {
+		return fmt.Errorf("短信配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.Archive.Validate(); err != nil {
+		return fmt.Errorf("归档配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.Bulk.Validate(); err != nil {
+		return fmt.Errorf("批量操作配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.Grpc.Validate(); err != nil {
+		return fmt.Errorf("gRPC配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.EventBus.Validate(); err != nil {
+		return fmt.Errorf("事件总线配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.Webhooks.Validate(); err != nil {
+		return fmt.Errorf("Webhook配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.OpenAPI.Validate(); err != nil {
+		return fmt.Errorf("接口文档配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.FaultInjection.Validate(); err != nil {
+		return fmt.Errorf("故障注入配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.DebugMode.Validate(); err != nil {
+		return fmt.Errorf("调试模式配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.Inspector.Validate(); err != nil {
+		return fmt.Errorf("请求检查器配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.ModelCache.Validate(); err != nil {
+		return fmt.Errorf("模型缓存配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.Trash.Validate(); err != nil {
+		return fmt.Errorf("回收站配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.Cleanup.Validate(); err != nil {
+		return fmt.Errorf("过期数据清理配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.Impersonation.Validate(); err != nil {
+		return fmt.Errorf("模拟登录配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.Approval.Validate(); err != nil {
+		return fmt.Errorf("双人审批配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.UserSettings.Validate(); err != nil {
+		return fmt.Errorf("用户偏好配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.Teams.Validate(); err != nil {
+		return fmt.Errorf("组织和团队配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.Metering.Validate(); err != nil {
+		return fmt.Errorf("用量计量配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.Billing.Validate(); err != nil {
+		return fmt.Errorf("计费配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.Export.Validate(); err != nil {
+		return fmt.Errorf("数据导出配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.RequestSigning.Validate(); err != nil {
+		return fmt.Errorf("请求签名配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.RequestTimeout.Validate(); err != nil {
+		return fmt.Errorf("请求超时配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.MiddlewarePipeline.Validate(); err != nil {
+		return fmt.Errorf("中间件管道配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.Gateway.Validate(); err != nil {
+		return fmt.Errorf("网关策略配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.Compression.Validate(); err != nil {
+		return fmt.Errorf("响应压缩配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.TLS.Validate(); err != nil {
+		return fmt.Errorf("TLS配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.Listeners.Validate(); err != nil {
+		return fmt.Errorf("多端口监听配置验证失败: %v", err)
+	}
+
+	if err := ValidateListeners(globalConfig); err != nil {
+		return fmt.Errorf("多端口监听配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.Startup.Validate(); err != nil {
+		return fmt.Errorf("启动依赖等待配置验证失败: %v", err)
+	}
+
+	if err := globalConfig.Migration.Validate(); err != nil {
+		return fmt.Errorf("迁移预检配置验证失败: %v", err)
+	}
+
 	// 验证JWT密钥安全性
 	if len(globalConfig.JWT.Secret) < 32 {
 		return fmt.Errorf("JWT密钥长度不足，建议至少32个字符")
@@ -0,0 +1,90 @@
+package Config
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 更换套餐时的按比例计费方式，与 Stripe proration_behavior 参数一致
+const (
+	BillingProrationCreate = "create_prorations" // 生成按比例计费项，在下一张账单中结算
+	BillingProrationAlways = "always_invoice"    // 生成按比例计费项并立即开具账单
+	BillingProrationNone   = "none"              // 不按比例计费，新价格从下个周期生效
+)
+
+// BillingConfig 计费配置
+// 功能说明：
+// 1. 可选模块，启用后用户通过 Stripe 订阅套餐，套餐决定用量限额和可用的高级功能
+// 2. 订阅状态通过 Stripe Webhook 同步，Webhook 使用签名密钥校验
+// 3. 更换套餐时按 ProrationBehavior 按比例计费
+// 4. 未订阅或订阅已失效的用户使用用量计量配置中的默认限额，只能使用 FreeFeatures 中的功能
+type BillingConfig struct {
+	Enabled             bool          `mapstructure:"enabled"`               // 是否启用计费
+	StripeSecretKey     string        `mapstructure:"stripe_secret_key"`     // Stripe API密钥
+	StripeWebhookSecret string        `mapstructure:"stripe_webhook_secret"` // Stripe Webhook签名密钥（whsec_开头）
+	StripeEndpoint      string        `mapstructure:"stripe_endpoint"`       // Stripe API地址，可指向本地模拟服务
+	WebhookTolerance    time.Duration `mapstructure:"webhook_tolerance"`     // Webhook签名时间戳允许的偏差
+	ProrationBehavior   string        `mapstructure:"proration_behavior"`    // 更换套餐的按比例计费方式
+	CheckoutSuccessURL  string        `mapstructure:"checkout_success_url"`  // 支付成功后跳转的前端地址
+	CheckoutCancelURL   string        `mapstructure:"checkout_cancel_url"`   // 取消支付后跳转的前端地址
+	FreeFeatures        string        `mapstructure:"free_features"`         // 未订阅用户可用的功能，逗号分隔
+	BasicHistoryRange   time.Duration `mapstructure:"basic_history_range"`   // 没有长期指标保留功能时历史指标的最大查询范围
+}
+
+// SetDefaults 设置计费默认值
+func (b *BillingConfig) SetDefaults() {
+	viper.SetDefault("billing.enabled", false)
+	viper.SetDefault("billing.stripe_endpoint", "https://api.stripe.com")
+	viper.SetDefault("billing.webhook_tolerance", "5m")
+	viper.SetDefault("billing.proration_behavior", BillingProrationCreate)
+	viper.SetDefault("billing.free_features", "")
+	viper.SetDefault("billing.basic_history_range", "168h")
+}
+
+// BindEnvs 绑定计费环境变量
+func (b *BillingConfig) BindEnvs() {
+	viper.BindEnv("billing.enabled", "BILLING_ENABLED")
+	viper.BindEnv("billing.stripe_secret_key", "BILLING_STRIPE_SECRET_KEY")
+	viper.BindEnv("billing.stripe_webhook_secret", "BILLING_STRIPE_WEBHOOK_SECRET")
+	viper.BindEnv("billing.stripe_endpoint", "BILLING_STRIPE_ENDPOINT")
+	viper.BindEnv("billing.webhook_tolerance", "BILLING_WEBHOOK_TOLERANCE")
+	viper.BindEnv("billing.proration_behavior", "BILLING_PRORATION_BEHAVIOR")
+	viper.BindEnv("billing.checkout_success_url", "BILLING_CHECKOUT_SUCCESS_URL")
+	viper.BindEnv("billing.checkout_cancel_url", "BILLING_CHECKOUT_CANCEL_URL")
+	viper.BindEnv("billing.free_features", "BILLING_FREE_FEATURES")
+	viper.BindEnv("billing.basic_history_range", "BILLING_BASIC_HISTORY_RANGE")
+}
+
+// Validate 验证计费配置
+func (b *BillingConfig) Validate() error {
+	if !b.Enabled {
+		return nil
+	}
+	if b.StripeSecretKey == "" || b.StripeWebhookSecret == "" {
+		return fmt.Errorf("启用计费时必须配置 Stripe API密钥和Webhook签名密钥")
+	}
+	if b.CheckoutSuccessURL == "" || b.CheckoutCancelURL == "" {
+		return fmt.Errorf("启用计费时必须配置支付成功和取消后的跳转地址")
+	}
+	if !slices.Contains([]string{BillingProrationCreate, BillingProrationAlways, BillingProrationNone}, b.ProrationBehavior) {
+		return fmt.Errorf("按比例计费方式无效: %s，可选值为 create_prorations、always_invoice、none", b.ProrationBehavior)
+	}
+	if b.WebhookTolerance <= 0 {
+		return fmt.Errorf("Webhook签名时间戳允许的偏差必须大于0")
+	}
+	if b.BasicHistoryRange < time.Hour {
+		return fmt.Errorf("历史指标的最大查询范围不能少于1小时，当前值: %v", b.BasicHistoryRange)
+	}
+	return nil
+}
+
+// GetBillingConfig 获取计费配置
+func GetBillingConfig() *BillingConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.Billing
+}
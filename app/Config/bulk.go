@@ -0,0 +1,66 @@
+package Config
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+// BulkConfig 批量操作配置
+// 功能说明：
+// 1. 用户导入、批量角色分配和批量启用/禁用作为后台任务执行，Workers 为并发执行的任务数
+// 2. MaxRows 限制单个任务的行数，MaxUploadSize 限制导入文件大小
+// 3. 每处理 ProgressEvery 行保存一次进度和错误明细，服务重启后从上次保存的位置继续
+type BulkConfig struct {
+	Workers       int   `mapstructure:"workers"`         // 同时执行的任务数
+	QueueSize     int   `mapstructure:"queue_size"`      // 任务队列长度，队列满时任务保留在数据库中稍后执行
+	MaxRows       int   `mapstructure:"max_rows"`        // 单个任务最大行数
+	MaxUploadSize int64 `mapstructure:"max_upload_size"` // 导入文件最大字节数
+	ProgressEvery int   `mapstructure:"progress_every"`  // 保存进度的间隔行数
+}
+
+// SetDefaults 设置批量操作配置默认值
+func (b *BulkConfig) SetDefaults() {
+	viper.SetDefault("bulk.workers", 2)
+	viper.SetDefault("bulk.queue_size", 100)
+	viper.SetDefault("bulk.max_rows", 10000)
+	viper.SetDefault("bulk.max_upload_size", 10<<20) // 10MB
+	viper.SetDefault("bulk.progress_every", 100)
+}
+
+// BindEnvs 绑定批量操作环境变量
+func (b *BulkConfig) BindEnvs() {
+	viper.BindEnv("bulk.workers", "BULK_WORKERS")
+	viper.BindEnv("bulk.queue_size", "BULK_QUEUE_SIZE")
+	viper.BindEnv("bulk.max_rows", "BULK_MAX_ROWS")
+	viper.BindEnv("bulk.max_upload_size", "BULK_MAX_UPLOAD_SIZE")
+	viper.BindEnv("bulk.progress_every", "BULK_PROGRESS_EVERY")
+}
+
+// Validate 验证批量操作配置
+func (b *BulkConfig) Validate() error {
+	if b.Workers <= 0 {
+		return fmt.Errorf("批量任务并发数必须大于0")
+	}
+	if b.QueueSize <= 0 {
+		return fmt.Errorf("批量任务队列长度必须大于0")
+	}
+	if b.MaxRows <= 0 {
+		return fmt.Errorf("批量任务最大行数必须大于0")
+	}
+	if b.MaxUploadSize <= 0 {
+		return fmt.Errorf("导入文件大小限制必须大于0")
+	}
+	if b.ProgressEvery <= 0 {
+		return fmt.Errorf("进度保存间隔必须大于0")
+	}
+	return nil
+}
+
+// GetBulkConfig 获取批量操作配置
+func GetBulkConfig() *BulkConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.Bulk
+}
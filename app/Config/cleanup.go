@@ -0,0 +1,65 @@
+package Config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// CleanupConfig 过期数据清理配置
+// 功能说明：
+// 1. 过期的指标采样、通知、请求签名随机数、Webhook投递记录、已投递事件、扫描结果、报告文件和评分快照由清理调度器统一清理
+// 2. 每隔 Interval 依次运行各清理任务，Enabled 为false时不自动运行，仍可以由管理员手动触发
+// 3. 每批最多删除 BatchSize 条记录，批次之间暂停 BatchPause，避免长时间锁表
+// 4. 单个任务运行超过 MaxRunDuration 时停止，剩余记录在下次运行时继续清理，为0时不限制
+type CleanupConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`          // 是否按间隔自动清理
+	Interval       time.Duration `mapstructure:"interval"`         // 自动清理间隔
+	BatchSize      int           `mapstructure:"batch_size"`       // 每批删除的记录数
+	BatchPause     time.Duration `mapstructure:"batch_pause"`      // 批次之间的暂停时间
+	MaxRunDuration time.Duration `mapstructure:"max_run_duration"` // 单个任务单次运行的最长时间
+}
+
+// SetDefaults 设置过期数据清理默认值
+func (c *CleanupConfig) SetDefaults() {
+	viper.SetDefault("cleanup.enabled", true)
+	viper.SetDefault("cleanup.interval", "1h")
+	viper.SetDefault("cleanup.batch_size", 1000)
+	viper.SetDefault("cleanup.batch_pause", "100ms")
+	viper.SetDefault("cleanup.max_run_duration", "10m")
+}
+
+// BindEnvs 绑定过期数据清理环境变量
+func (c *CleanupConfig) BindEnvs() {
+	viper.BindEnv("cleanup.enabled", "CLEANUP_ENABLED")
+	viper.BindEnv("cleanup.interval", "CLEANUP_INTERVAL")
+	viper.BindEnv("cleanup.batch_size", "CLEANUP_BATCH_SIZE")
+	viper.BindEnv("cleanup.batch_pause", "CLEANUP_BATCH_PAUSE")
+	viper.BindEnv("cleanup.max_run_duration", "CLEANUP_MAX_RUN_DURATION")
+}
+
+// Validate 验证过期数据清理配置
+func (c *CleanupConfig) Validate() error {
+	if c.Enabled && c.Interval <= 0 {
+		return fmt.Errorf("自动清理间隔必须大于0")
+	}
+	if c.BatchSize <= 0 {
+		return fmt.Errorf("每批删除的记录数必须大于0")
+	}
+	if c.BatchPause < 0 {
+		return fmt.Errorf("批次之间的暂停时间不能为负数")
+	}
+	if c.MaxRunDuration < 0 {
+		return fmt.Errorf("单次清理的最长运行时间不能为负数")
+	}
+	return nil
+}
+
+// GetCleanupConfig 获取过期数据清理配置
+func GetCleanupConfig() *CleanupConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.Cleanup
+}
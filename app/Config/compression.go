@@ -0,0 +1,129 @@
+package Config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// 响应压缩算法
+const (
+	CompressionBrotli = "br"
+	CompressionGzip   = "gzip"
+)
+
+// CompressionConfig 响应压缩配置
+// 功能说明：
+// 1. 按 Accept-Encoding 协商 br 或 gzip，客户端权重相同时按 Algorithms 的顺序选择
+// 2. 响应体达到 MinSize 才压缩；处理器主动刷新（流式响应）时立即开始压缩，每次刷新都把已压缩的数据写出
+// 3. 只压缩 ContentTypes 中的类型，text/event-stream 需要显式列出才压缩，通配符不匹配事件流
+// 4. WebSocket升级请求、Range请求和 ExcludePaths 中的路由分组不压缩
+type CompressionConfig struct {
+	Enabled      bool   `mapstructure:"enabled"`
+	Algorithms   string `mapstructure:"algorithms"`    // 支持的算法，按优先顺序，如 "br,gzip"
+	MinSize      int    `mapstructure:"min_size"`      // 压缩的最小响应体字节数
+	ContentTypes string `mapstructure:"content_types"` // 压缩的内容类型，支持 text/* 形式的通配符
+	ExcludePaths string `mapstructure:"exclude_paths"` // 不压缩的路由分组，按路径段匹配前缀
+	GzipLevel    int    `mapstructure:"gzip_level"`    // gzip 压缩级别 1-9
+	BrotliLevel  int    `mapstructure:"brotli_level"`  // brotli 压缩级别 0-11
+}
+
+// SetDefaults 设置响应压缩默认值
+func (c *CompressionConfig) SetDefaults() {
+	viper.SetDefault("compression.enabled", false)
+	viper.SetDefault("compression.algorithms", "br,gzip")
+	viper.SetDefault("compression.min_size", 1024)
+	viper.SetDefault("compression.content_types", "application/json,application/problem+json,application/javascript,application/xml,text/html,text/plain,text/css,text/csv,text/xml,image/svg+xml")
+	viper.SetDefault("compression.exclude_paths", "/api/v1/ws,/ws")
+	viper.SetDefault("compression.gzip_level", 5)
+	viper.SetDefault("compression.brotli_level", 4)
+}
+
+// BindEnvs 绑定响应压缩环境变量
+func (c *CompressionConfig) BindEnvs() {
+	viper.BindEnv("compression.enabled", "COMPRESSION_ENABLED")
+	viper.BindEnv("compression.algorithms", "COMPRESSION_ALGORITHMS")
+	viper.BindEnv("compression.min_size", "COMPRESSION_MIN_SIZE")
+	viper.BindEnv("compression.content_types", "COMPRESSION_CONTENT_TYPES")
+	viper.BindEnv("compression.exclude_paths", "COMPRESSION_EXCLUDE_PATHS")
+	viper.BindEnv("compression.gzip_level", "COMPRESSION_GZIP_LEVEL")
+	viper.BindEnv("compression.brotli_level", "COMPRESSION_BROTLI_LEVEL")
+}
+
+// Validate 验证响应压缩配置，未启用时不检查
+func (c *CompressionConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if _, err := c.AlgorithmList(); err != nil {
+		return err
+	}
+	if c.MinSize < 0 {
+		return fmt.Errorf("压缩的最小响应体字节数不能为负数")
+	}
+	if len(c.ContentTypeList()) == 0 {
+		return fmt.Errorf("至少需要配置一个压缩的内容类型")
+	}
+	if c.GzipLevel < 1 || c.GzipLevel > 9 {
+		return fmt.Errorf("gzip 压缩级别必须在1到9之间")
+	}
+	if c.BrotliLevel < 0 || c.BrotliLevel > 11 {
+		return fmt.Errorf("brotli 压缩级别必须在0到11之间")
+	}
+	for _, path := range c.ExcludePathList() {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("不压缩的路由分组无效: %s", path)
+		}
+	}
+	return nil
+}
+
+// AlgorithmList 解析支持的算法
+func (c *CompressionConfig) AlgorithmList() ([]string, error) {
+	var algorithms []string
+	for _, item := range strings.Split(c.Algorithms, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" {
+			continue
+		}
+		if item != CompressionBrotli && item != CompressionGzip {
+			return nil, fmt.Errorf("不支持的压缩算法: %s", item)
+		}
+		algorithms = append(algorithms, item)
+	}
+	if len(algorithms) == 0 {
+		return nil, fmt.Errorf("至少需要配置一个压缩算法")
+	}
+	return algorithms, nil
+}
+
+// ContentTypeList 解析压缩的内容类型
+func (c *CompressionConfig) ContentTypeList() []string {
+	var types []string
+	for _, item := range strings.Split(c.ContentTypes, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			types = append(types, item)
+		}
+	}
+	return types
+}
+
+// ExcludePathList 解析不压缩的路由分组
+func (c *CompressionConfig) ExcludePathList() []string {
+	var paths []string
+	for _, item := range strings.Split(c.ExcludePaths, ",") {
+		if item = strings.TrimRight(strings.TrimSpace(item), "/"); item != "" {
+			paths = append(paths, item)
+		}
+	}
+	return paths
+}
+
+// GetCompressionConfig 获取响应压缩配置
+func GetCompressionConfig() *CompressionConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.Compression
+}
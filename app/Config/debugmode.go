@@ -0,0 +1,68 @@
+package Config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// DebugModeConfig 请求调试模式配置
+// 功能说明：
+// 1. 启用后管理员在请求中携带调试请求头时，记录该请求执行的SQL、缓存操作和出站HTTP调用
+// 2. 记录按请求ID保存在内存中，通过 /api/v1/admin/debug/requests/{request_id} 查看，响应头 X-Debug-Id 为记录的请求ID
+// 3. 记录超过 TTL 或数量超过 MaxRequests 时丢弃最早的记录
+// 4. 单个请求最多记录 MaxEntries 条操作，超出部分只计数
+type DebugModeConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	Header      string        `mapstructure:"header"`       // 开启调试的请求头，值为 1 或 true 时开启
+	TTL         time.Duration `mapstructure:"ttl"`          // 记录保留时间
+	MaxRequests int           `mapstructure:"max_requests"` // 最多保留的请求数
+	MaxEntries  int           `mapstructure:"max_entries"`  // 单个请求最多记录的操作数
+}
+
+// SetDefaults 设置调试模式默认值
+func (d *DebugModeConfig) SetDefaults() {
+	viper.SetDefault("debug_mode.enabled", false)
+	viper.SetDefault("debug_mode.header", "X-Debug")
+	viper.SetDefault("debug_mode.ttl", "15m")
+	viper.SetDefault("debug_mode.max_requests", 200)
+	viper.SetDefault("debug_mode.max_entries", 500)
+}
+
+// BindEnvs 绑定调试模式环境变量
+func (d *DebugModeConfig) BindEnvs() {
+	viper.BindEnv("debug_mode.enabled", "DEBUG_MODE_ENABLED")
+	viper.BindEnv("debug_mode.header", "DEBUG_MODE_HEADER")
+	viper.BindEnv("debug_mode.ttl", "DEBUG_MODE_TTL")
+	viper.BindEnv("debug_mode.max_requests", "DEBUG_MODE_MAX_REQUESTS")
+	viper.BindEnv("debug_mode.max_entries", "DEBUG_MODE_MAX_ENTRIES")
+}
+
+// Validate 验证调试模式配置，未启用时不检查
+func (d *DebugModeConfig) Validate() error {
+	if !d.Enabled {
+		return nil
+	}
+	if d.Header == "" {
+		return fmt.Errorf("调试请求头不能为空")
+	}
+	if d.TTL <= 0 {
+		return fmt.Errorf("调试记录保留时间必须大于0")
+	}
+	if d.MaxRequests <= 0 {
+		return fmt.Errorf("调试记录最多保留的请求数必须大于0")
+	}
+	if d.MaxEntries <= 0 {
+		return fmt.Errorf("单个请求最多记录的操作数必须大于0")
+	}
+	return nil
+}
+
+// GetDebugModeConfig 获取调试模式配置
+func GetDebugModeConfig() *DebugModeConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.DebugMode
+}
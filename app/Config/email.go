@@ -3,63 +3,146 @@ package Config
 import (
 	"fmt"
 	"github.com/spf13/viper"
+	"time"
+)
+
+// 邮件发送驱动
+const (
+	MailDriverSMTP     = "smtp"
+	MailDriverSendGrid = "sendgrid"
+	MailDriverSES      = "ses"
+	MailDriverMailgun  = "mailgun"
+	MailDriverLog      = "log" // 只记录日志不发送，用于开发和测试环境
 )
 
 // EmailConfig 邮件配置
 type EmailConfig struct {
+	Driver   string `mapstructure:"driver"` // 发送驱动：smtp、sendgrid、ses、mailgun、log
 	Host     string `mapstructure:"host"`
 	Port     int    `mapstructure:"port"`
 	Username string `mapstructure:"username"`
 	Password string `mapstructure:"password"`
 	From     string `mapstructure:"from"`
+	FromName string `mapstructure:"from_name"` // 发件人显示名称
 	UseTLS   bool   `mapstructure:"use_tls"`
+
+	// 第三方服务商配置
+	APIKey          string `mapstructure:"api_key"`           // SendGrid/Mailgun API密钥
+	Domain          string `mapstructure:"domain"`            // Mailgun发信域名
+	Region          string `mapstructure:"region"`            // SES区域，Mailgun区域(us/eu)
+	AccessKeyID     string `mapstructure:"access_key_id"`     // SES访问密钥ID
+	SecretAccessKey string `mapstructure:"secret_access_key"` // SES访问密钥
+	Endpoint        string `mapstructure:"endpoint"`          // 覆盖服务商API地址（代理或本地模拟服务）
+
+	// 模板、队列和重试
+	TemplatePath  string        `mapstructure:"template_path"`  // 邮件模板目录
+	Timeout       time.Duration `mapstructure:"timeout"`        // 单次发送超时
+	QueueWorkers  int           `mapstructure:"queue_workers"`  // 异步发送协程数
+	QueueSize     int           `mapstructure:"queue_size"`     // 发送队列长度
+	MaxRetries    int           `mapstructure:"max_retries"`    // 临时失败最大重试次数
+	RetryDelay    time.Duration `mapstructure:"retry_delay"`    // 首次重试延迟，之后按指数增长
+	WebhookSecret string        `mapstructure:"webhook_secret"` // 退信回调接口校验令牌
 }
 
 // SetDefaults 设置默认值
 func (e *EmailConfig) SetDefaults() {
+	viper.SetDefault("email.driver", MailDriverSMTP)
 	viper.SetDefault("email.host", "smtp.gmail.com")
 	viper.SetDefault("email.port", 587)
 	viper.SetDefault("email.username", "")
 	viper.SetDefault("email.password", "")
 	viper.SetDefault("email.from", "noreply@example.com")
+	viper.SetDefault("email.from_name", "")
 	viper.SetDefault("email.use_tls", true)
+	viper.SetDefault("email.region", "")
+	viper.SetDefault("email.template_path", "storage/templates/emails")
+	viper.SetDefault("email.timeout", "10s")
+	viper.SetDefault("email.queue_workers", 2)
+	viper.SetDefault("email.queue_size", 1000)
+	viper.SetDefault("email.max_retries", 3)
+	viper.SetDefault("email.retry_delay", "30s")
 }
 
 // BindEnvs 绑定环境变量
 func (e *EmailConfig) BindEnvs() {
+	viper.BindEnv("email.driver", "EMAIL_DRIVER")
 	viper.BindEnv("email.host", "EMAIL_HOST")
 	viper.BindEnv("email.port", "EMAIL_PORT")
 	viper.BindEnv("email.username", "EMAIL_USERNAME")
 	viper.BindEnv("email.password", "EMAIL_PASSWORD")
 	viper.BindEnv("email.from", "EMAIL_FROM")
+	viper.BindEnv("email.from_name", "EMAIL_FROM_NAME")
 	viper.BindEnv("email.use_tls", "EMAIL_USE_TLS")
+	viper.BindEnv("email.api_key", "EMAIL_API_KEY")
+	viper.BindEnv("email.domain", "EMAIL_DOMAIN")
+	viper.BindEnv("email.region", "EMAIL_REGION")
+	viper.BindEnv("email.access_key_id", "EMAIL_ACCESS_KEY_ID")
+	viper.BindEnv("email.secret_access_key", "EMAIL_SECRET_ACCESS_KEY")
+	viper.BindEnv("email.endpoint", "EMAIL_ENDPOINT")
+	viper.BindEnv("email.template_path", "EMAIL_TEMPLATE_PATH")
+	viper.BindEnv("email.timeout", "EMAIL_TIMEOUT")
+	viper.BindEnv("email.queue_workers", "EMAIL_QUEUE_WORKERS")
+	viper.BindEnv("email.queue_size", "EMAIL_QUEUE_SIZE")
+	viper.BindEnv("email.max_retries", "EMAIL_MAX_RETRIES")
+	viper.BindEnv("email.retry_delay", "EMAIL_RETRY_DELAY")
+	viper.BindEnv("email.webhook_secret", "EMAIL_WEBHOOK_SECRET")
 }
 
 // Validate 验证配置
 func (e *EmailConfig) Validate() error {
-	if e.Host == "" {
-		return fmt.Errorf("邮件服务器地址不能为空")
-	}
-	
-	if e.Port <= 0 || e.Port > 65535 {
-		return fmt.Errorf("邮件服务器端口无效: %d", e.Port)
-	}
-	
-	if e.Username == "" {
-		return fmt.Errorf("邮件用户名不能为空")
-	}
-	
-	if e.Password == "" {
-		return fmt.Errorf("邮件密码不能为空")
-	}
-	
 	if e.From == "" {
 		return fmt.Errorf("发件人地址不能为空")
 	}
-	
+
+	switch e.GetDriver() {
+	case MailDriverSMTP:
+		if e.Host == "" {
+			return fmt.Errorf("邮件服务器地址不能为空")
+		}
+
+		if e.Port <= 0 || e.Port > 65535 {
+			return fmt.Errorf("邮件服务器端口无效: %d", e.Port)
+		}
+
+		if e.Username == "" {
+			return fmt.Errorf("邮件用户名不能为空")
+		}
+
+		if e.Password == "" {
+			return fmt.Errorf("邮件密码不能为空")
+		}
+	case MailDriverSendGrid:
+		if e.APIKey == "" {
+			return fmt.Errorf("SendGrid API密钥不能为空")
+		}
+	case MailDriverMailgun:
+		if e.APIKey == "" || e.Domain == "" {
+			return fmt.Errorf("Mailgun API密钥和发信域名不能为空")
+		}
+	case MailDriverSES:
+		if e.Region == "" || e.AccessKeyID == "" || e.SecretAccessKey == "" {
+			return fmt.Errorf("SES区域和访问密钥不能为空")
+		}
+	case MailDriverLog:
+	default:
+		return fmt.Errorf("不支持的邮件驱动: %s", e.Driver)
+	}
+
+	if e.QueueWorkers < 0 || e.QueueSize < 0 || e.MaxRetries < 0 {
+		return fmt.Errorf("邮件队列协程数、队列长度和重试次数不能为负数")
+	}
+
 	return nil
 }
 
+// GetDriver 获取发送驱动，未配置时使用SMTP
+func (e *EmailConfig) GetDriver() string {
+	if e.Driver == "" {
+		return MailDriverSMTP
+	}
+	return e.Driver
+}
+
 // GetDSN 获取邮件服务器DSN
 func (e *EmailConfig) GetDSN() string {
 	return fmt.Sprintf("%s:%d", e.Host, e.Port)
@@ -67,5 +150,16 @@ func (e *EmailConfig) GetDSN() string {
 
 // IsConfigured 检查是否已配置
 func (e *EmailConfig) IsConfigured() bool {
-	return e.Host != "" && e.Username != "" && e.Password != ""
+	switch e.GetDriver() {
+	case MailDriverSendGrid:
+		return e.APIKey != ""
+	case MailDriverMailgun:
+		return e.APIKey != "" && e.Domain != ""
+	case MailDriverSES:
+		return e.AccessKeyID != "" && e.SecretAccessKey != ""
+	case MailDriverLog:
+		return true
+	default:
+		return e.Host != "" && e.Username != "" && e.Password != ""
+	}
 }
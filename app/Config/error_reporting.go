@@ -0,0 +1,121 @@
+package Config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrorReportingConfig 错误上报配置
+//
+// panic 和通过 LogError 记录的错误转发到 Sentry 或 Bugsnag，附带版本、环境标签、
+// 请求信息、用户ID和请求内的面包屑。上报在后台队列中异步发送，不阻塞请求。
+// 事件内容在发送前按日志脱敏规则处理，SendDefaultPII 为 false 时不发送客户端IP、Cookie 和邮箱地址。
+type ErrorReportingConfig struct {
+	Enabled     bool   `mapstructure:"enabled"`     // 是否启用错误上报
+	Provider    string `mapstructure:"provider"`    // 上报服务：sentry、bugsnag
+	DSN         string `mapstructure:"dsn"`         // Sentry DSN，如 https://key@o0.ingest.sentry.io/123
+	APIKey      string `mapstructure:"api_key"`     // Bugsnag 项目 API Key
+	Endpoint    string `mapstructure:"endpoint"`    // Bugsnag 上报地址（自建服务时修改）
+	Release     string `mapstructure:"release"`     // 版本标签，如 git 提交或版本号
+	Environment string `mapstructure:"environment"` // 环境标签，为空时使用 server.environment
+
+	SampleRate         float64 `mapstructure:"sample_rate"`           // 错误的采样比例（0-1）
+	PanicSampleRate    float64 `mapstructure:"panic_sample_rate"`     // panic 的采样比例（0-1）
+	MaxEventsPerMinute int     `mapstructure:"max_events_per_minute"` // 每分钟最多上报的事件数，0 表示不限制
+	MaxBreadcrumbs     int     `mapstructure:"max_breadcrumbs"`       // 每个请求保留的面包屑条数
+	SendDefaultPII     bool    `mapstructure:"send_default_pii"`      // 是否发送客户端IP、Cookie 等个人信息
+
+	QueueSize int           `mapstructure:"queue_size"` // 待发送事件队列容量，满时丢弃
+	Timeout   time.Duration `mapstructure:"timeout"`    // 单次上报请求超时
+}
+
+// SetDefaults 设置错误上报默认值
+func (c *ErrorReportingConfig) SetDefaults() {
+	c.Enabled = false
+	c.Provider = "sentry"
+	c.Endpoint = "https://notify.bugsnag.com"
+	c.SampleRate = 1
+	c.PanicSampleRate = 1
+	c.MaxEventsPerMinute = 60
+	c.MaxBreadcrumbs = 30
+	c.SendDefaultPII = false
+	c.QueueSize = 100
+	c.Timeout = 5 * time.Second
+}
+
+// BindEnvs 绑定错误上报环境变量
+func (c *ErrorReportingConfig) BindEnvs(prefix string) {
+	bindEnv(prefix+"_ENABLED", &c.Enabled)
+	bindEnv(prefix+"_PROVIDER", &c.Provider)
+	bindEnv(prefix+"_DSN", &c.DSN)
+	bindEnv(prefix+"_API_KEY", &c.APIKey)
+	bindEnv(prefix+"_ENDPOINT", &c.Endpoint)
+	bindEnv(prefix+"_RELEASE", &c.Release)
+	bindEnv(prefix+"_ENVIRONMENT", &c.Environment)
+	bindEnv(prefix+"_SAMPLE_RATE", &c.SampleRate)
+	bindEnv(prefix+"_PANIC_SAMPLE_RATE", &c.PanicSampleRate)
+	bindEnv(prefix+"_MAX_EVENTS_PER_MINUTE", &c.MaxEventsPerMinute)
+	bindEnv(prefix+"_MAX_BREADCRUMBS", &c.MaxBreadcrumbs)
+	bindEnv(prefix+"_SEND_DEFAULT_PII", &c.SendDefaultPII)
+	bindEnv(prefix+"_QUEUE_SIZE", &c.QueueSize)
+	bindEnv(prefix+"_TIMEOUT", &c.Timeout)
+}
+
+// Validate 验证错误上报配置
+func (c *ErrorReportingConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	switch c.Provider {
+	case "sentry":
+		if _, _, err := ParseSentryDSN(c.DSN); err != nil {
+			return err
+		}
+	case "bugsnag":
+		if c.APIKey == "" {
+			return fmt.Errorf("Bugsnag API Key未配置")
+		}
+		if _, err := url.ParseRequestURI(c.Endpoint); err != nil {
+			return fmt.Errorf("无效的Bugsnag上报地址: %s", c.Endpoint)
+		}
+	default:
+		return fmt.Errorf("不支持的错误上报服务: %s", c.Provider)
+	}
+	if c.SampleRate < 0 || c.SampleRate > 1 {
+		return fmt.Errorf("错误采样比例必须在0到1之间")
+	}
+	if c.PanicSampleRate < 0 || c.PanicSampleRate > 1 {
+		return fmt.Errorf("panic采样比例必须在0到1之间")
+	}
+	if c.MaxEventsPerMinute < 0 {
+		return fmt.Errorf("每分钟上报事件数不能为负数")
+	}
+	if c.MaxBreadcrumbs < 0 {
+		return fmt.Errorf("面包屑条数不能为负数")
+	}
+	if c.QueueSize <= 0 {
+		return fmt.Errorf("上报队列容量必须大于0")
+	}
+	return nil
+}
+
+// ParseSentryDSN 解析 Sentry DSN，返回事件上报地址（envelope 接口）和公钥
+func ParseSentryDSN(dsn string) (string, string, error) {
+	u, err := url.Parse(strings.TrimSpace(dsn))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User == nil {
+		return "", "", fmt.Errorf("无效的Sentry DSN")
+	}
+	key := u.User.Username()
+	path := strings.Trim(u.Path, "/")
+	if key == "" || path == "" {
+		return "", "", fmt.Errorf("无效的Sentry DSN")
+	}
+	// 路径最后一段是项目ID，之前的部分是自建服务的路径前缀
+	prefix, project := "", path
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		prefix, project = "/"+path[:i], path[i+1:]
+	}
+	return fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, prefix, project), key, nil
+}
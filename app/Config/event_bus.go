@@ -0,0 +1,159 @@
+package Config
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// EventBusConfig 领域事件总线配置
+// 功能说明：
+// 1. 服务发布的领域事件先与业务数据在同一事务中写入 outbox_events 表，再由后台分发器投递
+// 2. 分发器每 PollInterval 读取一批待投递事件，投递到进程内处理器和启用的外部消息系统
+// 3. 投递失败按 RetryBackoff 指数退避重试，超过 MaxAttempts 次后标记为失败，可由管理员手动重试
+// 4. 投递成功的事件保留 Retention 后删除
+type EventBusConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	PollInterval time.Duration `mapstructure:"poll_interval"` // 读取待投递事件的间隔
+	BatchSize    int           `mapstructure:"batch_size"`    // 每次读取的事件数
+	MaxAttempts  int           `mapstructure:"max_attempts"`  // 最大投递次数
+	RetryBackoff time.Duration `mapstructure:"retry_backoff"` // 首次重试等待时间，之后指数增长，最长1小时
+	Retention    time.Duration `mapstructure:"retention"`     // 已投递事件的保留时间
+
+	Kafka    EventBusKafkaConfig    `mapstructure:"kafka"`    // Kafka（通过 REST Proxy 投递）
+	NATS     EventBusNATSConfig     `mapstructure:"nats"`     // NATS
+	RabbitMQ EventBusRabbitMQConfig `mapstructure:"rabbitmq"` // RabbitMQ（通过管理插件的HTTP接口投递）
+}
+
+// EventBusKafkaConfig Kafka 输出配置
+type EventBusKafkaConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	RestURL  string `mapstructure:"rest_url"` // Kafka REST Proxy 地址，如 http://kafka-rest:8082
+	Topic    string `mapstructure:"topic"`    // 主题，消息键为聚合ID
+	Username string `mapstructure:"username"` // Basic认证用户名
+	Password string `mapstructure:"password"` // Basic认证密码
+}
+
+// EventBusNATSConfig NATS 输出配置
+type EventBusNATSConfig struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	Address       string `mapstructure:"address"`        // 服务器地址，如 localhost:4222
+	SubjectPrefix string `mapstructure:"subject_prefix"` // 主题前缀，主题为前缀加事件类型，如 events.user.registered
+	Token         string `mapstructure:"token"`          // 认证令牌
+	Username      string `mapstructure:"username"`       // 用户名
+	Password      string `mapstructure:"password"`       // 密码
+}
+
+// EventBusRabbitMQConfig RabbitMQ 输出配置
+type EventBusRabbitMQConfig struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	ManagementURL string `mapstructure:"management_url"` // 管理插件地址，如 http://rabbitmq:15672
+	VHost         string `mapstructure:"vhost"`          // 虚拟主机
+	Exchange      string `mapstructure:"exchange"`       // 交换机，路由键为事件类型
+	Username      string `mapstructure:"username"`
+	Password      string `mapstructure:"password"`
+}
+
+// SetDefaults 设置事件总线默认值
+func (e *EventBusConfig) SetDefaults() {
+	viper.SetDefault("event_bus.enabled", false)
+	viper.SetDefault("event_bus.poll_interval", "1s")
+	viper.SetDefault("event_bus.batch_size", 100)
+	viper.SetDefault("event_bus.max_attempts", 10)
+	viper.SetDefault("event_bus.retry_backoff", "5s")
+	viper.SetDefault("event_bus.retention", "168h")
+
+	viper.SetDefault("event_bus.kafka.enabled", false)
+	viper.SetDefault("event_bus.kafka.rest_url", "http://localhost:8082")
+	viper.SetDefault("event_bus.kafka.topic", "domain-events")
+
+	viper.SetDefault("event_bus.nats.enabled", false)
+	viper.SetDefault("event_bus.nats.address", "localhost:4222")
+	viper.SetDefault("event_bus.nats.subject_prefix", "events.")
+
+	viper.SetDefault("event_bus.rabbitmq.enabled", false)
+	viper.SetDefault("event_bus.rabbitmq.management_url", "http://localhost:15672")
+	viper.SetDefault("event_bus.rabbitmq.vhost", "/")
+	viper.SetDefault("event_bus.rabbitmq.exchange", "domain_events")
+	viper.SetDefault("event_bus.rabbitmq.username", "guest")
+	viper.SetDefault("event_bus.rabbitmq.password", "guest")
+}
+
+// BindEnvs 绑定事件总线环境变量
+func (e *EventBusConfig) BindEnvs() {
+	viper.BindEnv("event_bus.enabled", "EVENT_BUS_ENABLED")
+	viper.BindEnv("event_bus.poll_interval", "EVENT_BUS_POLL_INTERVAL")
+	viper.BindEnv("event_bus.batch_size", "EVENT_BUS_BATCH_SIZE")
+	viper.BindEnv("event_bus.max_attempts", "EVENT_BUS_MAX_ATTEMPTS")
+	viper.BindEnv("event_bus.retry_backoff", "EVENT_BUS_RETRY_BACKOFF")
+	viper.BindEnv("event_bus.retention", "EVENT_BUS_RETENTION")
+
+	viper.BindEnv("event_bus.kafka.enabled", "EVENT_BUS_KAFKA_ENABLED")
+	viper.BindEnv("event_bus.kafka.rest_url", "EVENT_BUS_KAFKA_REST_URL")
+	viper.BindEnv("event_bus.kafka.topic", "EVENT_BUS_KAFKA_TOPIC")
+	viper.BindEnv("event_bus.kafka.username", "EVENT_BUS_KAFKA_USERNAME")
+	viper.BindEnv("event_bus.kafka.password", "EVENT_BUS_KAFKA_PASSWORD")
+
+	viper.BindEnv("event_bus.nats.enabled", "EVENT_BUS_NATS_ENABLED")
+	viper.BindEnv("event_bus.nats.address", "EVENT_BUS_NATS_ADDRESS")
+	viper.BindEnv("event_bus.nats.subject_prefix", "EVENT_BUS_NATS_SUBJECT_PREFIX")
+	viper.BindEnv("event_bus.nats.token", "EVENT_BUS_NATS_TOKEN")
+	viper.BindEnv("event_bus.nats.username", "EVENT_BUS_NATS_USERNAME")
+	viper.BindEnv("event_bus.nats.password", "EVENT_BUS_NATS_PASSWORD")
+
+	viper.BindEnv("event_bus.rabbitmq.enabled", "EVENT_BUS_RABBITMQ_ENABLED")
+	viper.BindEnv("event_bus.rabbitmq.management_url", "EVENT_BUS_RABBITMQ_MANAGEMENT_URL")
+	viper.BindEnv("event_bus.rabbitmq.vhost", "EVENT_BUS_RABBITMQ_VHOST")
+	viper.BindEnv("event_bus.rabbitmq.exchange", "EVENT_BUS_RABBITMQ_EXCHANGE")
+	viper.BindEnv("event_bus.rabbitmq.username", "EVENT_BUS_RABBITMQ_USERNAME")
+	viper.BindEnv("event_bus.rabbitmq.password", "EVENT_BUS_RABBITMQ_PASSWORD")
+}
+
+// Validate 验证事件总线配置，未启用时不检查
+func (e *EventBusConfig) Validate() error {
+	if !e.Enabled {
+		return nil
+	}
+	if e.PollInterval <= 0 {
+		return fmt.Errorf("事件读取间隔必须大于0")
+	}
+	if e.BatchSize <= 0 {
+		return fmt.Errorf("事件批次大小必须大于0")
+	}
+	if e.MaxAttempts <= 0 {
+		return fmt.Errorf("最大投递次数必须大于0")
+	}
+	if e.RetryBackoff <= 0 {
+		return fmt.Errorf("重试等待时间必须大于0")
+	}
+	if e.Kafka.Enabled {
+		if _, err := url.ParseRequestURI(e.Kafka.RestURL); err != nil {
+			return fmt.Errorf("无效的Kafka REST Proxy地址: %s", e.Kafka.RestURL)
+		}
+		if e.Kafka.Topic == "" {
+			return fmt.Errorf("Kafka主题未配置")
+		}
+	}
+	if e.NATS.Enabled && e.NATS.Address == "" {
+		return fmt.Errorf("NATS地址未配置")
+	}
+	if e.RabbitMQ.Enabled {
+		if _, err := url.ParseRequestURI(e.RabbitMQ.ManagementURL); err != nil {
+			return fmt.Errorf("无效的RabbitMQ管理地址: %s", e.RabbitMQ.ManagementURL)
+		}
+		if e.RabbitMQ.Exchange == "" {
+			return fmt.Errorf("RabbitMQ交换机未配置")
+		}
+	}
+	return nil
+}
+
+// GetEventBusConfig 获取事件总线配置
+func GetEventBusConfig() *EventBusConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.EventBus
+}
@@ -0,0 +1,57 @@
+package Config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// ExportConfig 数据导出配置
+// 功能说明：
+// 1. 告警、登录尝试和安全事件支持按列表接口的筛选条件流式导出为CSV或XLSX，MaxRows 限制单次下载的行数
+// 2. 定时导出按cron表达式运行，生成的文件通过邮件附件发送，附件最多 MaxAttachmentRows 行
+type ExportConfig struct {
+	MaxRows           int           `mapstructure:"max_rows"`            // 单次下载最多导出的行数，0表示不限制
+	ScheduleEnabled   bool          `mapstructure:"schedule_enabled"`    // 是否运行定时导出
+	CheckInterval     time.Duration `mapstructure:"check_interval"`      // 检查到期定时导出的间隔
+	MaxAttachmentRows int           `mapstructure:"max_attachment_rows"` // 定时导出邮件附件最多的行数
+}
+
+// SetDefaults 设置数据导出默认值
+func (e *ExportConfig) SetDefaults() {
+	viper.SetDefault("export.max_rows", 1000000)
+	viper.SetDefault("export.schedule_enabled", true)
+	viper.SetDefault("export.check_interval", "1m")
+	viper.SetDefault("export.max_attachment_rows", 50000)
+}
+
+// BindEnvs 绑定数据导出环境变量
+func (e *ExportConfig) BindEnvs() {
+	viper.BindEnv("export.max_rows", "EXPORT_MAX_ROWS")
+	viper.BindEnv("export.schedule_enabled", "EXPORT_SCHEDULE_ENABLED")
+	viper.BindEnv("export.check_interval", "EXPORT_CHECK_INTERVAL")
+	viper.BindEnv("export.max_attachment_rows", "EXPORT_MAX_ATTACHMENT_ROWS")
+}
+
+// Validate 验证数据导出配置
+func (e *ExportConfig) Validate() error {
+	if e.MaxRows < 0 {
+		return fmt.Errorf("导出最大行数不能为负数")
+	}
+	if e.ScheduleEnabled && e.CheckInterval <= 0 {
+		return fmt.Errorf("定时导出检查间隔必须大于0")
+	}
+	if e.MaxAttachmentRows <= 0 {
+		return fmt.Errorf("定时导出附件最大行数必须大于0")
+	}
+	return nil
+}
+
+// GetExportConfig 获取数据导出配置
+func GetExportConfig() *ExportConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.Export
+}
@@ -0,0 +1,61 @@
+package Config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// FaultInjectionConfig 故障注入配置
+// 功能说明：
+// 1. 启用后管理员可以通过 /api/v1/admin/fault-injections 按路由或依赖注入延迟、错误、数据库断连和Redis超时
+// 2. 用于在预发环境验证熔断、降级和告警，生产环境不要启用
+// 3. 每个注入都有有效期，到期自动失效，有效期不能超过 MaxDuration
+type FaultInjectionConfig struct {
+	Enabled         bool          `mapstructure:"enabled"`
+	DefaultDuration time.Duration `mapstructure:"default_duration"` // 未指定有效期时使用
+	MaxDuration     time.Duration `mapstructure:"max_duration"`     // 最长有效期
+	MaxLatency      time.Duration `mapstructure:"max_latency"`      // 单次注入的最大延迟
+}
+
+// SetDefaults 设置故障注入默认值
+func (f *FaultInjectionConfig) SetDefaults() {
+	viper.SetDefault("fault_injection.enabled", false)
+	viper.SetDefault("fault_injection.default_duration", "5m")
+	viper.SetDefault("fault_injection.max_duration", "1h")
+	viper.SetDefault("fault_injection.max_latency", "30s")
+}
+
+// BindEnvs 绑定故障注入环境变量
+func (f *FaultInjectionConfig) BindEnvs() {
+	viper.BindEnv("fault_injection.enabled", "FAULT_INJECTION_ENABLED")
+	viper.BindEnv("fault_injection.default_duration", "FAULT_INJECTION_DEFAULT_DURATION")
+	viper.BindEnv("fault_injection.max_duration", "FAULT_INJECTION_MAX_DURATION")
+	viper.BindEnv("fault_injection.max_latency", "FAULT_INJECTION_MAX_LATENCY")
+}
+
+// Validate 验证故障注入配置，未启用时不检查
+func (f *FaultInjectionConfig) Validate() error {
+	if !f.Enabled {
+		return nil
+	}
+	if f.DefaultDuration <= 0 {
+		return fmt.Errorf("故障注入默认有效期必须大于0")
+	}
+	if f.MaxDuration < f.DefaultDuration {
+		return fmt.Errorf("故障注入最长有效期不能小于默认有效期")
+	}
+	if f.MaxLatency <= 0 {
+		return fmt.Errorf("故障注入最大延迟必须大于0")
+	}
+	return nil
+}
+
+// GetFaultInjectionConfig 获取故障注入配置
+func GetFaultInjectionConfig() *FaultInjectionConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.FaultInjection
+}
@@ -0,0 +1,220 @@
+package Config
+
+import (
+	"fmt"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// GatewayRedactedValue 脱敏字段替换后的值
+const GatewayRedactedValue = "[REDACTED]"
+
+// GatewayConfig 网关策略配置
+// 功能说明：
+// 1. 按路由分组或路由注入、移除请求头，如移除客户端伪造的内部请求头
+// 2. 按用户角色脱敏JSON响应中的字段，字段在任意层级出现都会被替换
+// 3. 按路由限制请求体大小，超出时返回413
+// 4. 启用 MessagePack 后按 Accept 请求头协商响应格式，默认JSON；请求体为 MessagePack 时转换为JSON再交给处理器
+// 5. 路由范围的写法与中间件管道的 MIDDLEWARE_SCOPES 相同：路径为路由分组，"方法 路由模板" 为单个路由
+type GatewayConfig struct {
+	Enabled        bool   `mapstructure:"enabled"`
+	RequestHeaders string `mapstructure:"request_headers"` // 请求头策略，格式 "/api/v1=-X-Internal-Token|+X-Gateway:cloud-platform"
+	RedactFields   string `mapstructure:"redact_fields"`   // 响应字段脱敏，格式 "user=email|phone,*@/api/v1/admin=password"，* 表示所有角色
+	MaxBodySize    string `mapstructure:"max_body_size"`   // 未匹配路由时的请求体大小限制，如 10MB，0表示不限制
+	BodyLimits     string `mapstructure:"body_limits"`     // 路由请求体大小限制，格式 "/api/v1/files=100MB,POST /api/v1/auth/login=16KB"
+	MsgpackEnabled bool   `mapstructure:"msgpack_enabled"` // 是否支持 MessagePack 请求和响应
+}
+
+// GatewayHeader 注入的请求头
+type GatewayHeader struct {
+	Name  string
+	Value string
+}
+
+// GatewayHeaderRule 路由的请求头策略
+type GatewayHeaderRule struct {
+	Scope  MiddlewareScope
+	Remove []string
+	Set    []GatewayHeader
+}
+
+// GatewayRedactRule 角色的响应字段脱敏规则，Scope 为空时对所有路由生效
+type GatewayRedactRule struct {
+	Role   string
+	Scope  *MiddlewareScope
+	Fields []string
+}
+
+// GatewayBodyLimit 路由的请求体大小限制
+type GatewayBodyLimit struct {
+	Scope MiddlewareScope
+	Limit int64
+}
+
+// SetDefaults 设置网关策略默认值
+func (g *GatewayConfig) SetDefaults() {
+	viper.SetDefault("gateway.enabled", false)
+	viper.SetDefault("gateway.request_headers", "")
+	viper.SetDefault("gateway.redact_fields", "")
+	viper.SetDefault("gateway.max_body_size", "0")
+	viper.SetDefault("gateway.body_limits", "")
+	viper.SetDefault("gateway.msgpack_enabled", false)
+}
+
+// BindEnvs 绑定网关策略环境变量
+func (g *GatewayConfig) BindEnvs() {
+	viper.BindEnv("gateway.enabled", "GATEWAY_ENABLED")
+	viper.BindEnv("gateway.request_headers", "GATEWAY_REQUEST_HEADERS")
+	viper.BindEnv("gateway.redact_fields", "GATEWAY_REDACT_FIELDS")
+	viper.BindEnv("gateway.max_body_size", "GATEWAY_MAX_BODY_SIZE")
+	viper.BindEnv("gateway.body_limits", "GATEWAY_BODY_LIMITS")
+	viper.BindEnv("gateway.msgpack_enabled", "GATEWAY_MSGPACK_ENABLED")
+}
+
+// Validate 验证网关策略配置，未启用时不检查
+func (g *GatewayConfig) Validate() error {
+	if !g.Enabled {
+		return nil
+	}
+	if _, err := g.ParseRequestHeaders(); err != nil {
+		return err
+	}
+	if _, err := g.ParseRedactFields(); err != nil {
+		return err
+	}
+	if _, err := ParseByteSize(g.MaxBodySize); err != nil {
+		return fmt.Errorf("请求体大小限制无效: %v", err)
+	}
+	_, err := g.ParseBodyLimits()
+	return err
+}
+
+// ParseRequestHeaders 解析请求头策略，- 开头移除请求头，+ 开头注入请求头（名称和值用冒号分隔）
+func (g *GatewayConfig) ParseRequestHeaders() ([]GatewayHeaderRule, error) {
+	var rules []GatewayHeaderRule
+	for _, item := range splitMiddlewareList(g.RequestHeaders) {
+		raw, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("请求头策略无效: %s", item)
+		}
+		scope, err := parseMiddlewareScope(raw)
+		if err != nil || scope.Exclude {
+			return nil, fmt.Errorf("请求头策略的路由范围无效: %s", item)
+		}
+		rule := GatewayHeaderRule{Scope: scope}
+		for _, op := range strings.Split(value, "|") {
+			op = strings.TrimSpace(op)
+			switch {
+			case strings.HasPrefix(op, "-") && len(op) > 1:
+				rule.Remove = append(rule.Remove, textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(op[1:])))
+			case strings.HasPrefix(op, "+"):
+				name, headerValue, ok := strings.Cut(op[1:], ":")
+				name = strings.TrimSpace(name)
+				if !ok || name == "" {
+					return nil, fmt.Errorf("请求头策略无效: %s", item)
+				}
+				rule.Set = append(rule.Set, GatewayHeader{Name: textproto.CanonicalMIMEHeaderKey(name), Value: strings.TrimSpace(headerValue)})
+			default:
+				return nil, fmt.Errorf("请求头策略无效: %s", item)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ParseRedactFields 解析响应字段脱敏规则，角色后可以用 @ 限定路由范围
+func (g *GatewayConfig) ParseRedactFields() ([]GatewayRedactRule, error) {
+	var rules []GatewayRedactRule
+	for _, item := range splitMiddlewareList(g.RedactFields) {
+		key, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("响应字段脱敏规则无效: %s", item)
+		}
+		role, rawScope, scoped := strings.Cut(strings.TrimSpace(key), "@")
+		rule := GatewayRedactRule{Role: strings.TrimSpace(role)}
+		if rule.Role == "" {
+			return nil, fmt.Errorf("响应字段脱敏规则缺少角色: %s", item)
+		}
+		if scoped {
+			scope, err := parseMiddlewareScope(rawScope)
+			if err != nil || scope.Exclude {
+				return nil, fmt.Errorf("响应字段脱敏规则的路由范围无效: %s", item)
+			}
+			rule.Scope = &scope
+		}
+		for _, field := range strings.Split(value, "|") {
+			if field = strings.TrimSpace(field); field != "" {
+				rule.Fields = append(rule.Fields, field)
+			}
+		}
+		if len(rule.Fields) == 0 {
+			return nil, fmt.Errorf("响应字段脱敏规则缺少字段: %s", item)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ParseBodyLimits 解析路由请求体大小限制，单个路由优先，路由分组按前缀长度从长到短匹配
+func (g *GatewayConfig) ParseBodyLimits() ([]GatewayBodyLimit, error) {
+	var limits []GatewayBodyLimit
+	for _, item := range splitMiddlewareList(g.BodyLimits) {
+		raw, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("请求体大小限制无效: %s", item)
+		}
+		scope, err := parseMiddlewareScope(raw)
+		if err != nil || scope.Exclude {
+			return nil, fmt.Errorf("请求体大小限制的路由范围无效: %s", item)
+		}
+		limit, err := ParseByteSize(value)
+		if err != nil {
+			return nil, fmt.Errorf("请求体大小限制无效: %s", item)
+		}
+		limits = append(limits, GatewayBodyLimit{Scope: scope, Limit: limit})
+	}
+	sort.SliceStable(limits, func(i, j int) bool {
+		if limits[i].Scope.Route != limits[j].Scope.Route {
+			return limits[i].Scope.Route
+		}
+		return len(limits[i].Scope.Path) > len(limits[j].Scope.Path)
+	})
+	return limits, nil
+}
+
+// ParseByteSize 解析字节数，支持 KB、MB、GB 后缀（1024进制），空值表示0
+func ParseByteSize(value string) (int64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if value == "" {
+		return 0, nil
+	}
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(value, unit.suffix) {
+			multiplier = unit.size
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			break
+		}
+	}
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("字节数无效: %s", value)
+	}
+	return size * multiplier, nil
+}
+
+// GetGatewayConfig 获取网关策略配置
+func GetGatewayConfig() *GatewayConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.Gateway
+}
@@ -0,0 +1,91 @@
+package Config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// GrpcConfig 内部gRPC服务配置
+// 功能说明：
+// 1. 为内部微服务提供指标推送、告警查询和安全事件上报的gRPC接口，与REST接口使用同一套服务
+// 2. 只支持TLS上的HTTP/2，并强制双向TLS：客户端证书必须由 ClientCAFile 中的CA签发
+// 3. AllowedClients 不为空时只允许证书通用名称或DNS名称在列表中的客户端访问
+type GrpcConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	Address        string        `mapstructure:"address"`          // 监听地址，如 :9090
+	CertFile       string        `mapstructure:"cert_file"`        // 服务端证书
+	KeyFile        string        `mapstructure:"key_file"`         // 服务端私钥
+	ClientCAFile   string        `mapstructure:"client_ca_file"`   // 签发客户端证书的CA
+	AllowedClients string        `mapstructure:"allowed_clients"`  // 允许访问的客户端名称，逗号分隔，为空时不限制
+	MaxMessageSize int           `mapstructure:"max_message_size"` // 单个请求消息的最大字节数
+	RequestTimeout time.Duration `mapstructure:"request_timeout"`  // 客户端未指定 grpc-timeout 时的处理超时
+}
+
+// SetDefaults 设置gRPC配置默认值
+func (g *GrpcConfig) SetDefaults() {
+	viper.SetDefault("grpc.enabled", false)
+	viper.SetDefault("grpc.address", ":9090")
+	viper.SetDefault("grpc.cert_file", "")
+	viper.SetDefault("grpc.key_file", "")
+	viper.SetDefault("grpc.client_ca_file", "")
+	viper.SetDefault("grpc.allowed_clients", "")
+	viper.SetDefault("grpc.max_message_size", 4<<20) // 4MB
+	viper.SetDefault("grpc.request_timeout", "30s")
+}
+
+// BindEnvs 绑定gRPC环境变量
+func (g *GrpcConfig) BindEnvs() {
+	viper.BindEnv("grpc.enabled", "GRPC_ENABLED")
+	viper.BindEnv("grpc.address", "GRPC_ADDRESS")
+	viper.BindEnv("grpc.cert_file", "GRPC_CERT_FILE")
+	viper.BindEnv("grpc.key_file", "GRPC_KEY_FILE")
+	viper.BindEnv("grpc.client_ca_file", "GRPC_CLIENT_CA_FILE")
+	viper.BindEnv("grpc.allowed_clients", "GRPC_ALLOWED_CLIENTS")
+	viper.BindEnv("grpc.max_message_size", "GRPC_MAX_MESSAGE_SIZE")
+	viper.BindEnv("grpc.request_timeout", "GRPC_REQUEST_TIMEOUT")
+}
+
+// Validate 验证gRPC配置，未启用时不检查
+func (g *GrpcConfig) Validate() error {
+	if !g.Enabled {
+		return nil
+	}
+	if g.Address == "" {
+		return fmt.Errorf("gRPC监听地址不能为空")
+	}
+	if g.CertFile == "" || g.KeyFile == "" {
+		return fmt.Errorf("启用gRPC时必须配置服务端证书和私钥")
+	}
+	if g.ClientCAFile == "" {
+		return fmt.Errorf("启用gRPC时必须配置客户端CA证书（双向TLS）")
+	}
+	if g.MaxMessageSize <= 0 {
+		return fmt.Errorf("gRPC消息大小限制必须大于0")
+	}
+	if g.RequestTimeout <= 0 {
+		return fmt.Errorf("gRPC请求超时必须大于0")
+	}
+	return nil
+}
+
+// AllowedClientNames 解析允许访问的客户端名称
+func (g *GrpcConfig) AllowedClientNames() []string {
+	var names []string
+	for _, name := range strings.Split(g.AllowedClients, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// GetGrpcConfig 获取gRPC配置
+func GetGrpcConfig() *GrpcConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.Grpc
+}
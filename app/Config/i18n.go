@@ -0,0 +1,73 @@
+package Config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// I18nConfig 国际化配置
+//
+// 配置项说明：
+// - DefaultLocale: 默认语言，无法从请求中解析语言时使用
+// - FallbackLocale: 回退语言，当前语言缺少翻译键时使用
+// - Path: 外部语言包目录（JSON/YAML），会覆盖内置语言包中的同名键
+// - Supported: 支持的语言列表，为空时使用已加载的全部语言
+// - QueryParam: 允许通过查询参数切换语言（如 ?lang=en）
+type I18nConfig struct {
+	DefaultLocale  string   `mapstructure:"default_locale"`
+	FallbackLocale string   `mapstructure:"fallback_locale"`
+	Path           string   `mapstructure:"path"`
+	Supported      []string `mapstructure:"supported"`
+	QueryParam     string   `mapstructure:"query_param"`
+}
+
+// SetDefaults 设置国际化配置默认值
+func (i *I18nConfig) SetDefaults() {
+	viper.SetDefault("i18n.default_locale", "zh-CN")
+	viper.SetDefault("i18n.fallback_locale", "en")
+	viper.SetDefault("i18n.path", "./resources/lang")
+	viper.SetDefault("i18n.supported", []string{"zh-CN", "en"})
+	viper.SetDefault("i18n.query_param", "lang")
+}
+
+// BindEnvs 绑定国际化环境变量
+func (i *I18nConfig) BindEnvs() {
+	viper.BindEnv("i18n.default_locale", "I18N_DEFAULT_LOCALE")
+	viper.BindEnv("i18n.fallback_locale", "I18N_FALLBACK_LOCALE")
+	viper.BindEnv("i18n.path", "I18N_PATH")
+	viper.BindEnv("i18n.supported", "I18N_SUPPORTED")
+	viper.BindEnv("i18n.query_param", "I18N_QUERY_PARAM")
+}
+
+// Validate 验证国际化配置
+func (i *I18nConfig) Validate() error {
+	if i.DefaultLocale == "" {
+		return fmt.Errorf("默认语言未配置")
+	}
+
+	if len(i.Supported) > 0 && !i.IsSupported(i.DefaultLocale) {
+		return fmt.Errorf("默认语言 %s 不在支持的语言列表中", i.DefaultLocale)
+	}
+
+	return nil
+}
+
+// IsSupported 检查语言是否在支持列表中（不区分大小写）
+func (i *I18nConfig) IsSupported(locale string) bool {
+	for _, supported := range i.Supported {
+		if strings.EqualFold(supported, locale) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetI18nConfig 获取国际化配置
+func GetI18nConfig() *I18nConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.I18n
+}
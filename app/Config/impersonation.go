@@ -0,0 +1,76 @@
+package Config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// ImpersonationConfig 管理员模拟登录配置
+// 功能说明：
+// 1. 管理员可以签发以目标用户身份访问的模拟令牌，用于排查用户反馈的问题
+// 2. 令牌有效期默认 DefaultTTL，管理员指定的有效期不能超过 MaxTTL，模拟令牌不能刷新
+// 3. 模拟期间每个请求都记录审计日志，RestrictedRoutes 中的敏感操作（修改密码、创建API密钥等）返回403
+// 4. RestrictedRoutes 每项为 "方法 路径"，方法为 * 时匹配所有方法，路径以 * 结尾时按前缀匹配
+type ImpersonationConfig struct {
+	Enabled          bool          `mapstructure:"enabled"`
+	DefaultTTL       time.Duration `mapstructure:"default_ttl"`       // 未指定时模拟令牌的有效期
+	MaxTTL           time.Duration `mapstructure:"max_ttl"`           // 模拟令牌的最长有效期
+	RestrictedRoutes string        `mapstructure:"restricted_routes"` // 模拟期间禁止访问的路由，逗号分隔
+}
+
+// SetDefaults 设置模拟登录默认值
+func (i *ImpersonationConfig) SetDefaults() {
+	viper.SetDefault("impersonation.enabled", true)
+	viper.SetDefault("impersonation.default_ttl", "30m")
+	viper.SetDefault("impersonation.max_ttl", "2h")
+	viper.SetDefault("impersonation.restricted_routes", "POST /api/v1/auth/change-password,POST /api/v1/auth/mfa/*,POST /api/v1/api-keys*")
+}
+
+// BindEnvs 绑定模拟登录环境变量
+func (i *ImpersonationConfig) BindEnvs() {
+	viper.BindEnv("impersonation.enabled", "IMPERSONATION_ENABLED")
+	viper.BindEnv("impersonation.default_ttl", "IMPERSONATION_DEFAULT_TTL")
+	viper.BindEnv("impersonation.max_ttl", "IMPERSONATION_MAX_TTL")
+	viper.BindEnv("impersonation.restricted_routes", "IMPERSONATION_RESTRICTED_ROUTES")
+}
+
+// Validate 验证模拟登录配置，未启用时不检查
+func (i *ImpersonationConfig) Validate() error {
+	if !i.Enabled {
+		return nil
+	}
+	if i.DefaultTTL <= 0 || i.MaxTTL <= 0 {
+		return fmt.Errorf("模拟令牌有效期必须大于0")
+	}
+	if i.DefaultTTL > i.MaxTTL {
+		return fmt.Errorf("模拟令牌默认有效期不能超过最长有效期")
+	}
+	for _, route := range i.RestrictedRouteList() {
+		if len(strings.Fields(route)) != 2 {
+			return fmt.Errorf("模拟登录禁止路由格式无效: %s，应为 \"方法 路径\"", route)
+		}
+	}
+	return nil
+}
+
+// RestrictedRouteList 解析模拟期间禁止访问的路由
+func (i *ImpersonationConfig) RestrictedRouteList() []string {
+	var routes []string
+	for _, route := range strings.Split(i.RestrictedRoutes, ",") {
+		if route = strings.TrimSpace(route); route != "" {
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
+
+// GetImpersonationConfig 获取模拟登录配置
+func GetImpersonationConfig() *ImpersonationConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.Impersonation
+}
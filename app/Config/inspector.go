@@ -0,0 +1,137 @@
+package Config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// InspectorConfig 请求检查器配置
+// 功能说明：
+// 1. 启用后按采样率记录最近请求的请求、响应、异常、SQL、缓存操作、出站调用和通知，保存到 inspector_entries 表
+// 2. Environments 中的部署环境（APP_ENV）对所有用户采样；其他环境（如生产环境）只记录 UserIDs 中的用户
+// 3. 记录超过 Retention 或数量超过 MaxEntries 时由过期数据清理任务删除最早的记录；单个请求最多记录 MaxOperations 条操作，超出部分只计数
+// 4. 记录异步写入，队列已满时丢弃，不影响请求
+type InspectorConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	Environments  string        `mapstructure:"environments"`   // 对所有用户采样的部署环境，逗号分隔
+	UserIDs       string        `mapstructure:"user_ids"`       // 任何环境都记录的用户ID，逗号分隔
+	SampleRate    float64       `mapstructure:"sample_rate"`    // 采样率 (0,1]，指定用户的请求总是记录
+	IgnorePaths   string        `mapstructure:"ignore_paths"`   // 不记录的路由分组，按路径段匹配前缀
+	MaxBodySize   int           `mapstructure:"max_body_size"`  // 请求体和响应体最多记录的字节数
+	Retention     time.Duration `mapstructure:"retention"`      // 记录保留时间
+	MaxEntries    int           `mapstructure:"max_entries"`    // 最多保留的记录数
+	MaxOperations int           `mapstructure:"max_operations"` // 单个请求最多记录的SQL、缓存操作、出站调用和通知数
+	QueueSize     int           `mapstructure:"queue_size"`     // 异步写入队列长度
+}
+
+// SetDefaults 设置请求检查器默认值
+func (c *InspectorConfig) SetDefaults() {
+	viper.SetDefault("inspector.enabled", false)
+	viper.SetDefault("inspector.environments", "development,testing,staging")
+	viper.SetDefault("inspector.user_ids", "")
+	viper.SetDefault("inspector.sample_rate", 1.0)
+	viper.SetDefault("inspector.ignore_paths", "/health,/metrics,/api/v1/ws,/ws,/api/v1/admin/inspector")
+	viper.SetDefault("inspector.max_body_size", 16384)
+	viper.SetDefault("inspector.retention", "24h")
+	viper.SetDefault("inspector.max_entries", 10000)
+	viper.SetDefault("inspector.max_operations", 500)
+	viper.SetDefault("inspector.queue_size", 1000)
+}
+
+// BindEnvs 绑定请求检查器环境变量
+func (c *InspectorConfig) BindEnvs() {
+	viper.BindEnv("inspector.enabled", "INSPECTOR_ENABLED")
+	viper.BindEnv("inspector.environments", "INSPECTOR_ENVIRONMENTS")
+	viper.BindEnv("inspector.user_ids", "INSPECTOR_USER_IDS")
+	viper.BindEnv("inspector.sample_rate", "INSPECTOR_SAMPLE_RATE")
+	viper.BindEnv("inspector.ignore_paths", "INSPECTOR_IGNORE_PATHS")
+	viper.BindEnv("inspector.max_body_size", "INSPECTOR_MAX_BODY_SIZE")
+	viper.BindEnv("inspector.retention", "INSPECTOR_RETENTION")
+	viper.BindEnv("inspector.max_entries", "INSPECTOR_MAX_ENTRIES")
+	viper.BindEnv("inspector.max_operations", "INSPECTOR_MAX_OPERATIONS")
+	viper.BindEnv("inspector.queue_size", "INSPECTOR_QUEUE_SIZE")
+}
+
+// Validate 验证请求检查器配置，未启用时不检查
+func (c *InspectorConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.SampleRate <= 0 || c.SampleRate > 1 {
+		return fmt.Errorf("请求检查器采样率必须在(0,1]之间")
+	}
+	if _, err := c.UserIDList(); err != nil {
+		return err
+	}
+	for _, path := range c.IgnorePathList() {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("请求检查器不记录的路由分组无效: %s", path)
+		}
+	}
+	if c.MaxBodySize < 0 {
+		return fmt.Errorf("请求检查器请求体最大记录字节数不能为负数")
+	}
+	if c.Retention <= 0 {
+		return fmt.Errorf("请求检查器记录保留时间必须大于0")
+	}
+	if c.MaxEntries <= 0 {
+		return fmt.Errorf("请求检查器最多保留的记录数必须大于0")
+	}
+	if c.MaxOperations <= 0 {
+		return fmt.Errorf("请求检查器单个请求最多记录的操作数必须大于0")
+	}
+	if c.QueueSize <= 0 {
+		return fmt.Errorf("请求检查器写入队列长度必须大于0")
+	}
+	return nil
+}
+
+// EnvironmentList 解析对所有用户采样的部署环境
+func (c *InspectorConfig) EnvironmentList() []string {
+	var environments []string
+	for _, item := range strings.Split(c.Environments, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			environments = append(environments, item)
+		}
+	}
+	return environments
+}
+
+// UserIDList 解析任何环境都记录的用户ID
+func (c *InspectorConfig) UserIDList() ([]uint, error) {
+	var ids []uint
+	for _, item := range strings.Split(c.UserIDs, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(item, 10, 64)
+		if err != nil || id == 0 {
+			return nil, fmt.Errorf("请求检查器用户ID无效: %s", item)
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
+
+// IgnorePathList 解析不记录的路由分组
+func (c *InspectorConfig) IgnorePathList() []string {
+	var paths []string
+	for _, item := range strings.Split(c.IgnorePaths, ",") {
+		if item = strings.TrimRight(strings.TrimSpace(item), "/"); item != "" {
+			paths = append(paths, item)
+		}
+	}
+	return paths
+}
+
+// GetInspectorConfig 获取请求检查器配置
+func GetInspectorConfig() *InspectorConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.Inspector
+}
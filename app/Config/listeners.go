@@ -0,0 +1,244 @@
+package Config
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 监听名称
+const (
+	ListenerAPI       = "api"
+	ListenerRedirect  = "redirect"
+	ListenerAdmin     = "admin"
+	ListenerMetrics   = "metrics"
+	ListenerDashboard = "dashboard"
+)
+
+// ListenerConfig 内部监听的端口、TLS和访问控制
+type ListenerConfig struct {
+	Port       string `mapstructure:"port"`        // 端口或监听地址，如 9090、127.0.0.1:9090
+	TLS        bool   `mapstructure:"tls"`         // 是否使用HTTPS
+	CertFile   string `mapstructure:"cert_file"`   // 独立的证书文件，为空时使用API端口的TLS证书
+	KeyFile    string `mapstructure:"key_file"`    // 独立的私钥文件
+	AllowedIPs string `mapstructure:"allowed_ips"` // 允许访问的IP或CIDR，逗号分隔，为空时不限制
+	AuthToken  string `mapstructure:"auth_token"`  // 请求需要携带的Bearer令牌，为空时不检查
+}
+
+// Addr 返回监听地址，只配置端口时监听所有网卡
+func (l *ListenerConfig) Addr() string {
+	port := strings.TrimSpace(l.Port)
+	if port == "" || strings.Contains(port, ":") {
+		return port
+	}
+	return ":" + port
+}
+
+// AllowedNetworks 解析允许访问的网段，单个IP视为 /32 或 /128
+func (l *ListenerConfig) AllowedNetworks() ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, item := range strings.Split(l.AllowedIPs, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return nil, fmt.Errorf("无效的IP地址: %s", item)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, fmt.Errorf("无效的网段: %s", item)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// validate 验证单个监听配置
+func (l *ListenerConfig) validate(name string) error {
+	if (l.CertFile == "") != (l.KeyFile == "") {
+		return fmt.Errorf("%s 监听的证书和私钥文件需要同时配置", name)
+	}
+	if l.CertFile != "" && !l.TLS {
+		return fmt.Errorf("%s 监听配置了证书文件但未启用TLS", name)
+	}
+	if _, err := l.AllowedNetworks(); err != nil {
+		return fmt.Errorf("%s 监听的IP白名单无效: %v", name, err)
+	}
+	return nil
+}
+
+// ServerListenersConfig 多端口监听配置
+// 功能说明：
+// 1. API端口之外可以启用独立的管理端口和指标端口，与仪表板端口一起由服务器管理器统一启动和优雅关闭
+// 2. 管理端口只提供 AdminPrefixes 下的路由，启用后这些路由不再通过API端口访问；指标端口只提供 /metrics，启用后API端口不再提供 /metrics
+// 3. 每个内部端口可以单独启用TLS（使用独立证书或API端口的证书）、限制来源IP和要求Bearer令牌
+// 4. 仪表板端口由 MONITORING_ENABLE_DASHBOARD 和 MONITORING_DASHBOARD_PORT 控制，页面使用JWT登录，不支持共享令牌
+// 5. 指标端口未配置端口时使用 MONITORING_METRICS_PORT
+// 6. ShutdownTimeout 为所有端口共用的优雅关闭时间，超时后强制关闭未完成的连接
+type ServerListenersConfig struct {
+	AdminEnabled    bool           `mapstructure:"admin_enabled"`
+	AdminPrefixes   string         `mapstructure:"admin_prefixes"` // 管理端口提供的路由前缀，逗号分隔
+	Admin           ListenerConfig `mapstructure:"admin"`
+	MetricsEnabled  bool           `mapstructure:"metrics_enabled"`
+	Metrics         ListenerConfig `mapstructure:"metrics"`
+	Dashboard       ListenerConfig `mapstructure:"dashboard"`
+	ShutdownTimeout time.Duration  `mapstructure:"shutdown_timeout"`
+}
+
+// SetDefaults 设置多端口监听默认值
+func (l *ServerListenersConfig) SetDefaults() {
+	viper.SetDefault("listeners.admin_enabled", false)
+	viper.SetDefault("listeners.admin_prefixes", "/api/v1/admin,/debug/pprof")
+	viper.SetDefault("listeners.admin.port", "8083")
+	viper.SetDefault("listeners.admin.tls", false)
+	viper.SetDefault("listeners.admin.cert_file", "")
+	viper.SetDefault("listeners.admin.key_file", "")
+	viper.SetDefault("listeners.admin.allowed_ips", "")
+	viper.SetDefault("listeners.admin.auth_token", "")
+	viper.SetDefault("listeners.metrics_enabled", false)
+	viper.SetDefault("listeners.metrics.port", "")
+	viper.SetDefault("listeners.metrics.tls", false)
+	viper.SetDefault("listeners.metrics.cert_file", "")
+	viper.SetDefault("listeners.metrics.key_file", "")
+	viper.SetDefault("listeners.metrics.allowed_ips", "")
+	viper.SetDefault("listeners.metrics.auth_token", "")
+	viper.SetDefault("listeners.dashboard.tls", false)
+	viper.SetDefault("listeners.dashboard.cert_file", "")
+	viper.SetDefault("listeners.dashboard.key_file", "")
+	viper.SetDefault("listeners.dashboard.allowed_ips", "")
+	viper.SetDefault("listeners.shutdown_timeout", "30s")
+}
+
+// BindEnvs 绑定多端口监听环境变量
+func (l *ServerListenersConfig) BindEnvs() {
+	viper.BindEnv("listeners.admin_enabled", "LISTENER_ADMIN_ENABLED")
+	viper.BindEnv("listeners.admin_prefixes", "LISTENER_ADMIN_PREFIXES")
+	viper.BindEnv("listeners.metrics_enabled", "LISTENER_METRICS_ENABLED")
+	for _, name := range []string{ListenerAdmin, ListenerMetrics, ListenerDashboard} {
+		prefix := "LISTENER_" + strings.ToUpper(name) + "_"
+		if name != ListenerDashboard {
+			viper.BindEnv("listeners."+name+".port", prefix+"PORT")
+			viper.BindEnv("listeners."+name+".auth_token", prefix+"AUTH_TOKEN")
+		}
+		viper.BindEnv("listeners."+name+".tls", prefix+"TLS")
+		viper.BindEnv("listeners."+name+".cert_file", prefix+"CERT_FILE")
+		viper.BindEnv("listeners."+name+".key_file", prefix+"KEY_FILE")
+		viper.BindEnv("listeners."+name+".allowed_ips", prefix+"ALLOWED_IPS")
+	}
+	viper.BindEnv("listeners.shutdown_timeout", "LISTENER_SHUTDOWN_TIMEOUT")
+}
+
+// Validate 验证多端口监听配置，端口冲突和TLS依赖由 ValidateListeners 检查
+func (l *ServerListenersConfig) Validate() error {
+	if l.ShutdownTimeout <= 0 {
+		return fmt.Errorf("优雅关闭时间必须大于0")
+	}
+	if l.AdminEnabled {
+		if l.Admin.Addr() == "" {
+			return fmt.Errorf("启用管理端口需要配置端口")
+		}
+		if len(l.AdminPrefixList()) == 0 {
+			return fmt.Errorf("启用管理端口需要配置路由前缀")
+		}
+		for _, prefix := range l.AdminPrefixList() {
+			if !strings.HasPrefix(prefix, "/") || prefix == "/" {
+				return fmt.Errorf("管理端口的路由前缀必须以 / 开头且不能为根路径: %s", prefix)
+			}
+		}
+	}
+	if l.Dashboard.AuthToken != "" {
+		return fmt.Errorf("仪表板页面使用JWT登录，不支持共享令牌")
+	}
+	for name, listener := range map[string]*ListenerConfig{ListenerAdmin: &l.Admin, ListenerMetrics: &l.Metrics, ListenerDashboard: &l.Dashboard} {
+		if err := listener.validate(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AdminPrefixList 解析管理端口的路由前缀
+func (l *ServerListenersConfig) AdminPrefixList() []string {
+	var prefixes []string
+	for _, prefix := range strings.Split(l.AdminPrefixes, ",") {
+		if prefix = strings.TrimRight(strings.TrimSpace(prefix), "/"); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// ListenerAddrs 返回启用的监听地址，键为监听名称
+func ListenerAddrs(c *Config) map[string]string {
+	addrs := map[string]string{ListenerAPI: ":" + c.Server.Port}
+	if c.TLS.Enabled && (c.TLS.RedirectHTTP || c.TLS.Autocert) {
+		addrs[ListenerRedirect] = ":" + c.TLS.HTTPPort
+	}
+	if c.Listeners.AdminEnabled {
+		addrs[ListenerAdmin] = c.Listeners.Admin.Addr()
+	}
+	if c.Listeners.MetricsEnabled {
+		addr := c.Listeners.Metrics.Addr()
+		if addr == "" {
+			addr = ":" + strconv.Itoa(c.Monitoring.BaseConfig.MetricsPort)
+		}
+		addrs[ListenerMetrics] = addr
+	}
+	if c.Monitoring.BaseConfig.EnableDashboard {
+		addrs[ListenerDashboard] = ":" + strconv.Itoa(c.Monitoring.BaseConfig.DashboardPort)
+	}
+	return addrs
+}
+
+// ValidateListeners 检查各监听的端口不冲突，内部端口使用API端口的证书时API端口需要启用TLS
+func ValidateListeners(c *Config) error {
+	addrs := ListenerAddrs(c)
+	names := make([]string, 0, len(addrs))
+	for name := range addrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ports := make(map[string]string)
+	for _, name := range names {
+		_, port, err := net.SplitHostPort(addrs[name])
+		if err != nil || port == "" {
+			return fmt.Errorf("%s 监听地址无效: %s", name, addrs[name])
+		}
+		if other, ok := ports[port]; ok {
+			return fmt.Errorf("%s 和 %s 监听同一端口 %s", other, name, port)
+		}
+		ports[port] = name
+	}
+
+	listeners := map[string]*ListenerConfig{ListenerAdmin: &c.Listeners.Admin, ListenerMetrics: &c.Listeners.Metrics, ListenerDashboard: &c.Listeners.Dashboard}
+	for _, name := range names {
+		listener, ok := listeners[name]
+		if ok && listener.TLS && listener.CertFile == "" && !c.TLS.Enabled {
+			return fmt.Errorf("%s 监听启用TLS但未配置证书，API端口也未启用TLS", name)
+		}
+	}
+	return nil
+}
+
+// GetServerListenersConfig 获取多端口监听配置
+func GetServerListenersConfig() *ServerListenersConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.Listeners
+}
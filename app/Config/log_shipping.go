@@ -0,0 +1,172 @@
+package Config
+
+import (
+	"fmt"
+	"time"
+)
+
+// LogShippingConfig 日志集中投递配置
+//
+// 日志写入本地文件后再投递到集中式日志系统，无需额外部署采集sidecar。
+// 每个输出都有独立的内存队列和磁盘缓冲，一个输出故障不影响其他输出。
+type LogShippingConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`         // 是否启用日志投递
+	BatchSize     int           `mapstructure:"batch_size"`      // 每批投递的日志条数
+	FlushInterval time.Duration `mapstructure:"flush_interval"`  // 未满一批时的最长等待时间
+	QueueSize     int           `mapstructure:"queue_size"`      // 每个输出的内存队列容量，满时溢出到磁盘
+	MaxRetries    int           `mapstructure:"max_retries"`     // 投递失败的重试次数
+	RetryBackoff  time.Duration `mapstructure:"retry_backoff"`   // 首次重试等待时间（之后指数增长）
+	BufferPath    string        `mapstructure:"buffer_path"`     // 失败缓冲目录（相对于日志基础路径）
+	MaxBufferSize int           `mapstructure:"max_buffer_size"` // 每个输出磁盘缓冲上限(MB)，超出时丢弃最旧的数据
+
+	Elasticsearch LogShippingElasticsearchConfig `mapstructure:"elasticsearch"` // Elasticsearch/OpenSearch 输出
+	Loki          LogShippingLokiConfig          `mapstructure:"loki"`          // Grafana Loki 输出
+	Syslog        LogShippingSyslogConfig        `mapstructure:"syslog"`        // Syslog 输出
+}
+
+// LogShippingElasticsearchConfig Elasticsearch 输出配置
+type LogShippingElasticsearchConfig struct {
+	Enabled   bool     `mapstructure:"enabled"`   // 是否启用
+	Loggers   []string `mapstructure:"loggers"`   // 投递的日志记录器，为空表示全部
+	MinLevel  LogLevel `mapstructure:"min_level"` // 最低投递级别
+	Addresses []string `mapstructure:"addresses"` // 节点地址
+	Username  string   `mapstructure:"username"`  // Basic认证用户名
+	Password  string   `mapstructure:"password"`  // Basic认证密码
+	APIKey    string   `mapstructure:"api_key"`   // API Key（优先于Basic认证）
+	Index     string   `mapstructure:"index"`     // 索引名模板，支持 {logger} 和 {date} 占位符
+}
+
+// LogShippingLokiConfig Loki 输出配置
+type LogShippingLokiConfig struct {
+	Enabled  bool     `mapstructure:"enabled"`   // 是否启用
+	Loggers  []string `mapstructure:"loggers"`   // 投递的日志记录器，为空表示全部
+	MinLevel LogLevel `mapstructure:"min_level"` // 最低投递级别
+	URL      string   `mapstructure:"url"`       // Loki地址（如 http://loki:3100）
+	TenantID string   `mapstructure:"tenant_id"` // 多租户ID（X-Scope-OrgID）
+	Username string   `mapstructure:"username"`  // Basic认证用户名
+	Password string   `mapstructure:"password"`  // Basic认证密码
+	Labels   []string `mapstructure:"labels"`    // 附加的静态标签，格式 key=value
+}
+
+// LogShippingSyslogConfig Syslog 输出配置
+type LogShippingSyslogConfig struct {
+	Enabled  bool     `mapstructure:"enabled"`   // 是否启用
+	Loggers  []string `mapstructure:"loggers"`   // 投递的日志记录器，为空表示全部
+	MinLevel LogLevel `mapstructure:"min_level"` // 最低投递级别
+	Network  string   `mapstructure:"network"`   // 网络类型：udp、tcp
+	Address  string   `mapstructure:"address"`   // 服务器地址（host:port）
+	Tag      string   `mapstructure:"tag"`       // 应用名（APP-NAME）
+	Facility int      `mapstructure:"facility"`  // 设施代码（默认16，即local0）
+}
+
+// SetDefaults 设置日志投递默认值
+func (c *LogShippingConfig) SetDefaults() {
+	c.Enabled = false
+	c.BatchSize = 500
+	c.FlushInterval = 2 * time.Second
+	c.QueueSize = 10000
+	c.MaxRetries = 3
+	c.RetryBackoff = 500 * time.Millisecond
+	c.BufferPath = "shipping"
+	c.MaxBufferSize = 256 // 256MB
+
+	c.Elasticsearch.MinLevel = LogLevelInfo
+	c.Elasticsearch.Addresses = []string{"http://localhost:9200"}
+	c.Elasticsearch.Index = "logs-{logger}-{date}"
+
+	c.Loki.MinLevel = LogLevelInfo
+	c.Loki.URL = "http://localhost:3100"
+	c.Loki.Labels = []string{"app=cloud-platform-api"}
+
+	c.Syslog.MinLevel = LogLevelWarning
+	c.Syslog.Network = "udp"
+	c.Syslog.Address = "localhost:514"
+	c.Syslog.Tag = "cloud-platform-api"
+	c.Syslog.Facility = 16
+}
+
+// BindEnvs 绑定日志投递环境变量
+func (c *LogShippingConfig) BindEnvs(prefix string) {
+	bindEnv(prefix+"_ENABLED", &c.Enabled)
+	bindEnv(prefix+"_BATCH_SIZE", &c.BatchSize)
+	bindEnv(prefix+"_FLUSH_INTERVAL", &c.FlushInterval)
+	bindEnv(prefix+"_QUEUE_SIZE", &c.QueueSize)
+	bindEnv(prefix+"_MAX_RETRIES", &c.MaxRetries)
+	bindEnv(prefix+"_RETRY_BACKOFF", &c.RetryBackoff)
+	bindEnv(prefix+"_BUFFER_PATH", &c.BufferPath)
+	bindEnv(prefix+"_MAX_BUFFER_SIZE", &c.MaxBufferSize)
+
+	bindEnv(prefix+"_ES_ENABLED", &c.Elasticsearch.Enabled)
+	bindEnv(prefix+"_ES_LOGGERS", &c.Elasticsearch.Loggers)
+	bindEnv(prefix+"_ES_MIN_LEVEL", (*string)(&c.Elasticsearch.MinLevel))
+	bindEnv(prefix+"_ES_ADDRESSES", &c.Elasticsearch.Addresses)
+	bindEnv(prefix+"_ES_USERNAME", &c.Elasticsearch.Username)
+	bindEnv(prefix+"_ES_PASSWORD", &c.Elasticsearch.Password)
+	bindEnv(prefix+"_ES_API_KEY", &c.Elasticsearch.APIKey)
+	bindEnv(prefix+"_ES_INDEX", &c.Elasticsearch.Index)
+
+	bindEnv(prefix+"_LOKI_ENABLED", &c.Loki.Enabled)
+	bindEnv(prefix+"_LOKI_LOGGERS", &c.Loki.Loggers)
+	bindEnv(prefix+"_LOKI_MIN_LEVEL", (*string)(&c.Loki.MinLevel))
+	bindEnv(prefix+"_LOKI_URL", &c.Loki.URL)
+	bindEnv(prefix+"_LOKI_TENANT_ID", &c.Loki.TenantID)
+	bindEnv(prefix+"_LOKI_USERNAME", &c.Loki.Username)
+	bindEnv(prefix+"_LOKI_PASSWORD", &c.Loki.Password)
+	bindEnv(prefix+"_LOKI_LABELS", &c.Loki.Labels)
+
+	bindEnv(prefix+"_SYSLOG_ENABLED", &c.Syslog.Enabled)
+	bindEnv(prefix+"_SYSLOG_LOGGERS", &c.Syslog.Loggers)
+	bindEnv(prefix+"_SYSLOG_MIN_LEVEL", (*string)(&c.Syslog.MinLevel))
+	bindEnv(prefix+"_SYSLOG_NETWORK", &c.Syslog.Network)
+	bindEnv(prefix+"_SYSLOG_ADDRESS", &c.Syslog.Address)
+	bindEnv(prefix+"_SYSLOG_TAG", &c.Syslog.Tag)
+	bindEnv(prefix+"_SYSLOG_FACILITY", &c.Syslog.Facility)
+}
+
+// Validate 验证日志投递配置
+func (c *LogShippingConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.BatchSize <= 0 {
+		return fmt.Errorf("批次大小必须大于0")
+	}
+	if c.QueueSize <= 0 {
+		return fmt.Errorf("队列容量必须大于0")
+	}
+
+	if c.Elasticsearch.Enabled {
+		if len(c.Elasticsearch.Addresses) == 0 {
+			return fmt.Errorf("Elasticsearch地址未配置")
+		}
+		if c.Elasticsearch.Index == "" {
+			return fmt.Errorf("Elasticsearch索引名未配置")
+		}
+		if !isValidLogLevel(c.Elasticsearch.MinLevel) {
+			return fmt.Errorf("无效的Elasticsearch投递级别: %s", c.Elasticsearch.MinLevel)
+		}
+	}
+	if c.Loki.Enabled {
+		if c.Loki.URL == "" {
+			return fmt.Errorf("Loki地址未配置")
+		}
+		if !isValidLogLevel(c.Loki.MinLevel) {
+			return fmt.Errorf("无效的Loki投递级别: %s", c.Loki.MinLevel)
+		}
+	}
+	if c.Syslog.Enabled {
+		if c.Syslog.Network != "udp" && c.Syslog.Network != "tcp" {
+			return fmt.Errorf("无效的Syslog网络类型: %s", c.Syslog.Network)
+		}
+		if c.Syslog.Address == "" {
+			return fmt.Errorf("Syslog地址未配置")
+		}
+		if c.Syslog.Facility < 0 || c.Syslog.Facility > 23 {
+			return fmt.Errorf("无效的Syslog设施代码: %d", c.Syslog.Facility)
+		}
+		if !isValidLogLevel(c.Syslog.MinLevel) {
+			return fmt.Errorf("无效的Syslog投递级别: %s", c.Syslog.MinLevel)
+		}
+	}
+	return nil
+}
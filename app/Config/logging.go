@@ -2,6 +2,9 @@ package Config
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,6 +19,26 @@ const (
 	LogLevelFatal   LogLevel = "fatal"
 )
 
+// Severity 获取日志级别的严重程度（数值越大越严重，未知级别返回-1）
+//
+// LogLevel 是字符串类型，比较级别高低时应使用该方法而不是直接比较字符串。
+func (l LogLevel) Severity() int {
+	switch l {
+	case LogLevelDebug:
+		return 0
+	case LogLevelInfo:
+		return 1
+	case LogLevelWarning:
+		return 2
+	case LogLevelError:
+		return 3
+	case LogLevelFatal:
+		return 4
+	default:
+		return -1
+	}
+}
+
 // LogFormat 日志格式
 type LogFormat string
 
@@ -23,14 +46,41 @@ const (
 	LogFormatJSON   LogFormat = "json"
 	LogFormatText   LogFormat = "text"
 	LogFormatCustom LogFormat = "custom"
+
+	// LogFormatCombined Apache/NGINX combined 格式，只用于请求日志和访问日志，可直接被 GoAccess、awstats 等工具解析
+	LogFormatCombined LogFormat = "combined"
+	// LogFormatCommon 通用日志格式（CLF），combined 格式去掉 Referer 和 User-Agent
+	LogFormatCommon LogFormat = "common"
 )
 
 // LogRotation 日志轮转配置
+//
+// 日志文件按天轮转，轮转后的文件压缩为 .log.gz；保留策略对每个日志记录器分别生效，
+// MaxAge、MaxBackups、MaxTotalSize 为0时表示不按该条件清理。
+// 审计日志使用 AUDIT_LOG_RETENTION 和 AUDIT_LOG_COMPRESS，不按文件数量和总大小清理。
 type LogRotation struct {
-	MaxSize    int           `mapstructure:"max_size"`    // 单个日志文件最大大小(MB)
-	MaxAge     time.Duration `mapstructure:"max_age"`     // 日志文件保留时间
-	MaxBackups int           `mapstructure:"max_backups"` // 保留的日志文件数量
-	Compress   bool          `mapstructure:"compress"`    // 是否压缩旧日志文件
+	MaxSize       int           `mapstructure:"max_size"`       // 单个日志文件最大大小(MB)
+	MaxAge        time.Duration `mapstructure:"max_age"`        // 日志文件保留时间
+	MaxBackups    int           `mapstructure:"max_backups"`    // 保留的日志文件数量
+	MaxTotalSize  int           `mapstructure:"max_total_size"` // 每个日志记录器的日志文件总大小上限(MB)，超出时删除最旧的文件
+	Compress      bool          `mapstructure:"compress"`       // 是否压缩旧日志文件
+	CheckInterval time.Duration `mapstructure:"check_interval"` // 压缩和清理的检查间隔
+	Archive       bool          `mapstructure:"archive"`        // 压缩后上传到归档存储（ARCHIVE_STORE），上传成功后才删除未压缩的文件
+	ArchivePrefix string        `mapstructure:"archive_prefix"` // 归档文件键前缀，文件键为 前缀/日志记录器/文件名
+}
+
+// Validate 验证日志轮转配置
+func (r *LogRotation) Validate() error {
+	if r.MaxAge < 0 || r.MaxBackups < 0 || r.MaxTotalSize < 0 {
+		return fmt.Errorf("日志保留时间、文件数量和总大小不能为负数")
+	}
+	if r.CheckInterval <= 0 {
+		return fmt.Errorf("日志清理检查间隔必须大于0")
+	}
+	if r.Archive && !r.Compress {
+		return fmt.Errorf("日志归档需要启用 LOG_COMPRESS")
+	}
+	return nil
 }
 
 // LogConfig 日志配置结构
@@ -55,6 +105,68 @@ type LogConfig struct {
 	SecurityLog SecurityLogConfig `mapstructure:"security_log"` // 安全日志配置
 	BusinessLog BusinessLogConfig `mapstructure:"business_log"` // 业务日志配置
 	AccessLog   AccessLogConfig   `mapstructure:"access_log"`   // 访问日志配置
+
+	// 异步队列配置
+	QueueSize              int           `mapstructure:"queue_size"`               // 异步日志队列容量
+	OverflowPolicy         string        `mapstructure:"overflow_policy"`          // 队列满时的处理方式: sync, block, drop, drop_oldest, sample
+	LoggerOverflowPolicies []string      `mapstructure:"logger_overflow_policies"` // 按日志记录器覆盖队列满时的处理方式，格式 logger=policy
+	OverflowBlockTimeout   time.Duration `mapstructure:"overflow_block_timeout"`   // block 和 sample 策略的最长等待时间，超时后丢弃
+	OverflowSampleRate     int           `mapstructure:"overflow_sample_rate"`     // sample 策略队列满时每N条保留1条
+	Workers                int           `mapstructure:"workers"`                  // 写入协程数
+	BatchSize              int           `mapstructure:"batch_size"`               // 写入协程每批最多处理的日志数，同一日志文件的日志合并为一次写入
+
+	// 采样配置
+	Sampling LogSamplingConfig `mapstructure:"sampling"` // 日志采样
+
+	// 脱敏配置
+	Masking LogMaskingConfig `mapstructure:"masking"` // 敏感信息脱敏
+
+	// 集中投递配置
+	Shipping LogShippingConfig `mapstructure:"shipping"` // 日志投递（Elasticsearch/Loki/Syslog）
+
+	// 错误上报配置
+	ErrorReporting ErrorReportingConfig `mapstructure:"error_reporting"` // panic和错误上报（Sentry/Bugsnag）
+}
+
+// LogSamplingConfig 日志采样配置
+//
+// 采样只作用于 Levels 中的级别（默认 debug 和 info），警告及以上级别的日志始终保留。
+// 采样率 N 表示每 N 条保留 1 条，1 表示不采样。
+type LogSamplingConfig struct {
+	Enabled     bool     `mapstructure:"enabled"`      // 是否启用采样
+	Levels      []string `mapstructure:"levels"`       // 参与采样的级别
+	Rate        int      `mapstructure:"rate"`         // 默认采样率
+	LoggerRates []string `mapstructure:"logger_rates"` // 按日志记录器覆盖采样率，格式 logger=N
+}
+
+// LogMaskingConfig 日志脱敏配置
+//
+// 脱敏在格式化和投递之前进行，对所有日志记录器生效。
+// Fields 按字段名匹配（不区分大小写，忽略 - 和 _，包含即匹配），匹配字段的值整体替换；
+// Rules 为内置的正则规则，Patterns 为自定义正则，作用于消息和所有字符串值。
+type LogMaskingConfig struct {
+	Enabled     bool     `mapstructure:"enabled"`     // 是否启用脱敏
+	Fields      []string `mapstructure:"fields"`      // 敏感字段名
+	Rules       []string `mapstructure:"rules"`       // 内置规则: authorization, jwt, card, key_value
+	Patterns    []string `mapstructure:"patterns"`    // 自定义正则，匹配内容整体替换
+	Replacement string   `mapstructure:"replacement"` // 替换文本
+}
+
+// Validate 验证日志脱敏配置
+func (c *LogMaskingConfig) Validate() error {
+	for _, rule := range c.Rules {
+		switch strings.TrimSpace(rule) {
+		case "authorization", "jwt", "card", "key_value", "":
+		default:
+			return fmt.Errorf("未知的脱敏规则: %s", rule)
+		}
+	}
+	for _, pattern := range c.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("无效的脱敏正则 %q: %v", pattern, err)
+		}
+	}
+	return nil
 }
 
 // RequestLogConfig 请求日志配置
@@ -62,11 +174,14 @@ type RequestLogConfig struct {
 	Enabled     bool      `mapstructure:"enabled"`       // 是否启用
 	Level       LogLevel  `mapstructure:"level"`         // 日志级别
 	Path        string    `mapstructure:"path"`          // 存储路径(相对于base_path)
-	Format      LogFormat `mapstructure:"format"`        // 日志格式
+	Format      LogFormat `mapstructure:"format"`        // 日志格式: json, text, combined, common
 	IncludeBody bool      `mapstructure:"include_body"`  // 是否包含请求/响应体
-	MaxBodySize int       `mapstructure:"max_body_size"` // 最大记录体大小(KB)
+	MaxBodySize int       `mapstructure:"max_body_size"` // 最大记录体大小(KB)，超出部分截断
 	FilterPaths []string  `mapstructure:"filter_paths"`  // 过滤的路径(不记录)
 	MaskFields  []string  `mapstructure:"mask_fields"`   // 需要脱敏的字段
+
+	BodyContentTypes []string `mapstructure:"body_content_types"` // 记录请求/响应体的内容类型（前缀匹配）
+	SkipBodyPaths    []string `mapstructure:"skip_body_paths"`    // 不记录请求/响应体的路径（前缀匹配或路由模板）
 }
 
 // SQLLogConfig SQL日志配置
@@ -129,7 +244,7 @@ type AccessLogConfig struct {
 	Enabled     bool      `mapstructure:"enabled"`      // 是否启用
 	Level       LogLevel  `mapstructure:"level"`        // 日志级别
 	Path        string    `mapstructure:"path"`         // 存储路径
-	Format      LogFormat `mapstructure:"format"`       // 日志格式
+	Format      LogFormat `mapstructure:"format"`       // 日志格式: json, text, combined, common
 	IncludeUser bool      `mapstructure:"include_user"` // 是否包含用户信息
 	IncludeIP   bool      `mapstructure:"include_ip"`   // 是否包含IP地址
 	IncludeUA   bool      `mapstructure:"include_ua"`   // 是否包含User-Agent
@@ -146,10 +261,14 @@ func (c *LogConfig) SetDefaults() {
 	c.BasePath = "./storage/logs"
 
 	// 设置轮转默认值 - 按天记录
-	c.Rotation.MaxSize = 0             // 0表示不按大小轮转，只按时间轮转
-	c.Rotation.MaxAge = 24 * time.Hour // 1天
-	c.Rotation.MaxBackups = 30         // 保留30天的日志文件
+	c.Rotation.MaxSize = 0                  // 0表示不按大小轮转，只按时间轮转
+	c.Rotation.MaxAge = 30 * 24 * time.Hour // 保留30天
+	c.Rotation.MaxBackups = 30              // 保留30个轮转后的日志文件
+	c.Rotation.MaxTotalSize = 0             // 不限制总大小
 	c.Rotation.Compress = true
+	c.Rotation.CheckInterval = time.Hour
+	c.Rotation.Archive = false
+	c.Rotation.ArchivePrefix = "logs/"
 
 	// 设置各类型日志默认值
 	c.RequestLog.SetDefaults()
@@ -159,6 +278,30 @@ func (c *LogConfig) SetDefaults() {
 	c.SecurityLog.SetDefaults()
 	c.BusinessLog.SetDefaults()
 	c.AccessLog.SetDefaults()
+
+	c.QueueSize = 1000
+	c.OverflowPolicy = LogOverflowSync
+	c.LoggerOverflowPolicies = nil
+	c.OverflowBlockTimeout = time.Second
+	c.OverflowSampleRate = 10
+	c.Workers = 2
+	c.BatchSize = 100
+	c.Sampling.Enabled = false
+	c.Sampling.Levels = []string{string(LogLevelDebug), string(LogLevelInfo)}
+	c.Sampling.Rate = 1
+	c.Sampling.LoggerRates = nil
+
+	c.Masking.Enabled = true
+	c.Masking.Fields = []string{
+		"password", "passwd", "secret", "token", "apikey", "authorization",
+		"cookie", "cardnumber", "creditcard", "cvv", "privatekey",
+	}
+	c.Masking.Rules = []string{"authorization", "jwt", "card", "key_value"}
+	c.Masking.Patterns = nil
+	c.Masking.Replacement = "***MASKED***"
+
+	c.Shipping.SetDefaults()
+	c.ErrorReporting.SetDefaults()
 }
 
 // BindEnvs 绑定环境变量
@@ -176,7 +319,11 @@ func (c *LogConfig) BindEnvs() {
 	bindEnv("LOG_MAX_SIZE", &c.Rotation.MaxSize)
 	bindEnv("LOG_MAX_AGE", &c.Rotation.MaxAge)
 	bindEnv("LOG_MAX_BACKUPS", &c.Rotation.MaxBackups)
+	bindEnv("LOG_MAX_TOTAL_SIZE", &c.Rotation.MaxTotalSize)
 	bindEnv("LOG_COMPRESS", &c.Rotation.Compress)
+	bindEnv("LOG_RETENTION_CHECK_INTERVAL", &c.Rotation.CheckInterval)
+	bindEnv("LOG_ARCHIVE_ENABLED", &c.Rotation.Archive)
+	bindEnv("LOG_ARCHIVE_PREFIX", &c.Rotation.ArchivePrefix)
 
 	// 各类型日志配置
 	c.RequestLog.BindEnvs("REQUEST_LOG")
@@ -186,6 +333,27 @@ func (c *LogConfig) BindEnvs() {
 	c.SecurityLog.BindEnvs("SECURITY_LOG")
 	c.BusinessLog.BindEnvs("BUSINESS_LOG")
 	c.AccessLog.BindEnvs("ACCESS_LOG")
+
+	// 异步队列和采样配置
+	bindEnv("LOG_QUEUE_SIZE", &c.QueueSize)
+	bindEnv("LOG_OVERFLOW_POLICY", &c.OverflowPolicy)
+	bindEnv("LOG_OVERFLOW_LOGGER_POLICIES", &c.LoggerOverflowPolicies)
+	bindEnv("LOG_OVERFLOW_BLOCK_TIMEOUT", &c.OverflowBlockTimeout)
+	bindEnv("LOG_OVERFLOW_SAMPLE_RATE", &c.OverflowSampleRate)
+	bindEnv("LOG_WORKERS", &c.Workers)
+	bindEnv("LOG_BATCH_SIZE", &c.BatchSize)
+	bindEnv("LOG_SAMPLING_ENABLED", &c.Sampling.Enabled)
+	bindEnv("LOG_SAMPLING_LEVELS", &c.Sampling.Levels)
+	bindEnv("LOG_SAMPLING_RATE", &c.Sampling.Rate)
+	bindEnv("LOG_SAMPLING_LOGGER_RATES", &c.Sampling.LoggerRates)
+	bindEnv("LOG_MASKING_ENABLED", &c.Masking.Enabled)
+	bindEnv("LOG_MASKING_FIELDS", &c.Masking.Fields)
+	bindEnv("LOG_MASKING_RULES", &c.Masking.Rules)
+	bindEnv("LOG_MASKING_PATTERNS", &c.Masking.Patterns)
+	bindEnv("LOG_MASKING_REPLACEMENT", &c.Masking.Replacement)
+
+	c.Shipping.BindEnvs("LOG_SHIPPING")
+	c.ErrorReporting.BindEnvs("ERROR_REPORTING")
 }
 
 // Validate 验证配置
@@ -205,6 +373,10 @@ func (c *LogConfig) Validate() error {
 		return fmt.Errorf("无效的输出方式: %s", c.Output)
 	}
 
+	if err := c.Rotation.Validate(); err != nil {
+		return fmt.Errorf("日志轮转配置错误: %v", err)
+	}
+
 	// 验证各类型日志配置
 	if err := c.RequestLog.Validate(); err != nil {
 		return fmt.Errorf("请求日志配置错误: %v", err)
@@ -227,10 +399,114 @@ func (c *LogConfig) Validate() error {
 	if err := c.AccessLog.Validate(); err != nil {
 		return fmt.Errorf("访问日志配置错误: %v", err)
 	}
+	if c.QueueSize <= 0 {
+		return fmt.Errorf("日志队列容量必须大于0")
+	}
+	if !isValidLogOverflowPolicy(c.OverflowPolicy) {
+		return fmt.Errorf("无效的队列溢出处理方式: %s", c.OverflowPolicy)
+	}
+	if _, err := c.ParseLoggerOverflowPolicies(); err != nil {
+		return err
+	}
+	if c.OverflowBlockTimeout <= 0 {
+		return fmt.Errorf("日志队列阻塞等待时间必须大于0")
+	}
+	if c.OverflowSampleRate < 1 {
+		return fmt.Errorf("日志队列溢出采样率必须大于等于1")
+	}
+	if c.Workers <= 0 {
+		return fmt.Errorf("日志写入协程数必须大于0")
+	}
+	if c.BatchSize <= 0 {
+		return fmt.Errorf("日志写入批量大小必须大于0")
+	}
+	if err := c.Sampling.Validate(); err != nil {
+		return fmt.Errorf("日志采样配置错误: %v", err)
+	}
+	if err := c.Masking.Validate(); err != nil {
+		return fmt.Errorf("日志脱敏配置错误: %v", err)
+	}
+	if err := c.Shipping.Validate(); err != nil {
+		return fmt.Errorf("日志投递配置错误: %v", err)
+	}
+	if err := c.ErrorReporting.Validate(); err != nil {
+		return fmt.Errorf("错误上报配置错误: %v", err)
+	}
 
 	return nil
 }
 
+// 日志队列已满时的处理方式
+const (
+	LogOverflowSync       = "sync"        // 同步写入，不丢日志但阻塞调用者
+	LogOverflowBlock      = "block"       // 阻塞等待队列空间，超时后丢弃
+	LogOverflowDrop       = "drop"        // 丢弃新日志并计数
+	LogOverflowDropOldest = "drop_oldest" // 丢弃队列中最旧的日志，保留新日志
+	LogOverflowSample     = "sample"      // 每N条保留1条（阻塞等待），其余丢弃
+)
+
+// isValidLogOverflowPolicy 检查队列溢出处理方式是否有效
+func isValidLogOverflowPolicy(policy string) bool {
+	switch policy {
+	case LogOverflowSync, LogOverflowBlock, LogOverflowDrop, LogOverflowDropOldest, LogOverflowSample:
+		return true
+	}
+	return false
+}
+
+// ParseLoggerOverflowPolicies 解析按日志记录器覆盖的队列溢出处理方式
+func (c *LogConfig) ParseLoggerOverflowPolicies() (map[string]string, error) {
+	policies := make(map[string]string, len(c.LoggerOverflowPolicies))
+	for _, item := range c.LoggerOverflowPolicies {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, policy, ok := strings.Cut(item, "=")
+		policy = strings.TrimSpace(policy)
+		if !ok || strings.TrimSpace(name) == "" || !isValidLogOverflowPolicy(policy) {
+			return nil, fmt.Errorf("无效的队列溢出处理方式配置: %s", item)
+		}
+		policies[strings.TrimSpace(name)] = policy
+	}
+	return policies, nil
+}
+
+// Validate 验证日志采样配置
+func (c *LogSamplingConfig) Validate() error {
+	if c.Rate < 1 {
+		return fmt.Errorf("采样率必须大于等于1")
+	}
+	for _, level := range c.Levels {
+		if !isValidLogLevel(LogLevel(strings.TrimSpace(level))) {
+			return fmt.Errorf("无效的采样级别: %s", level)
+		}
+	}
+	_, err := c.ParseLoggerRates()
+	return err
+}
+
+// ParseLoggerRates 解析按日志记录器覆盖的采样率
+func (c *LogSamplingConfig) ParseLoggerRates() (map[string]int, error) {
+	rates := make(map[string]int, len(c.LoggerRates))
+	for _, item := range c.LoggerRates {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("无效的采样率配置: %s", item)
+		}
+		rate, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || rate < 1 {
+			return nil, fmt.Errorf("无效的采样率配置: %s", item)
+		}
+		rates[strings.TrimSpace(name)] = rate
+	}
+	return rates, nil
+}
+
 // SetDefaults 设置请求日志默认值
 func (c *RequestLogConfig) SetDefaults() {
 	c.Enabled = true
@@ -238,9 +514,16 @@ func (c *RequestLogConfig) SetDefaults() {
 	c.Path = "requests" // 日志文件将自动添加日期后缀，如 requests-2025-01-20.log
 	c.Format = LogFormatJSON
 	c.IncludeBody = false
-	c.MaxBodySize = 1024 // 1KB
+	c.MaxBodySize = 1024 // 1MB
 	c.FilterPaths = []string{"/health", "/metrics"}
 	c.MaskFields = []string{"password", "token", "secret"}
+	c.BodyContentTypes = []string{"application/json", "application/problem+json"}
+	c.SkipBodyPaths = []string{"/api/v1/auth/"}
+}
+
+// MaxBodyBytes 最大记录体大小(字节)
+func (c *RequestLogConfig) MaxBodyBytes() int {
+	return c.MaxBodySize * 1024
 }
 
 // BindEnvs 绑定请求日志环境变量
@@ -253,6 +536,8 @@ func (c *RequestLogConfig) BindEnvs(prefix string) {
 	bindEnv(prefix+"_MAX_BODY_SIZE", &c.MaxBodySize)
 	bindEnv(prefix+"_FILTER_PATHS", &c.FilterPaths)
 	bindEnv(prefix+"_MASK_FIELDS", &c.MaskFields)
+	bindEnv(prefix+"_BODY_CONTENT_TYPES", &c.BodyContentTypes)
+	bindEnv(prefix+"_SKIP_BODY_PATHS", &c.SkipBodyPaths)
 }
 
 // Validate 验证请求日志配置
@@ -260,9 +545,12 @@ func (c *RequestLogConfig) Validate() error {
 	if !isValidLogLevel(c.Level) {
 		return fmt.Errorf("无效的日志级别: %s", c.Level)
 	}
-	if !isValidLogFormat(c.Format) {
+	if !isValidHTTPLogFormat(c.Format) {
 		return fmt.Errorf("无效的日志格式: %s", c.Format)
 	}
+	if c.IncludeBody && c.MaxBodySize <= 0 {
+		return fmt.Errorf("记录请求体时最大记录体大小必须大于0")
+	}
 	return nil
 }
 
@@ -460,7 +748,7 @@ func (c *AccessLogConfig) Validate() error {
 	if !isValidLogLevel(c.Level) {
 		return fmt.Errorf("无效的日志级别: %s", c.Level)
 	}
-	if !isValidLogFormat(c.Format) {
+	if !isValidHTTPLogFormat(c.Format) {
 		return fmt.Errorf("无效的日志格式: %s", c.Format)
 	}
 	return nil
@@ -484,3 +772,8 @@ func isValidLogFormat(format LogFormat) bool {
 		return false
 	}
 }
+
+// isValidHTTPLogFormat 请求日志和访问日志额外支持 combined 和 common 格式
+func isValidHTTPLogFormat(format LogFormat) bool {
+	return isValidLogFormat(format) || format == LogFormatCombined || format == LogFormatCommon
+}
@@ -0,0 +1,77 @@
+package Config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 用量限额周期
+const (
+	MeteringPeriodDay   = "day"
+	MeteringPeriodMonth = "month"
+)
+
+// MeteringConfig 用量计量和限额配置
+// 功能说明：
+// 1. 按租户（用户或指标推送来源）统计API调用次数、上传字节数、推送指标数和发送的邮件和短信通知数
+// 2. 用量按天汇总保存，可按日期范围导出用于计费
+// 3. 每个周期（自然日或自然月，UTC）内的用量超过限额时拒绝请求：API调用返回429，其他用量返回402
+// 4. 限额为0表示不限制，管理员可以为单个租户单独设置限额
+type MeteringConfig struct {
+	Enabled                bool          `mapstructure:"enabled"`                  // 是否启用用量计量
+	FlushInterval          time.Duration `mapstructure:"flush_interval"`           // 内存中的计数写入数据库的间隔
+	Period                 string        `mapstructure:"period"`                   // 限额周期：day、month
+	QuotaAPICalls          int64         `mapstructure:"quota_api_calls"`          // 每周期API调用次数限额
+	QuotaStorageBytes      int64         `mapstructure:"quota_storage_bytes"`      // 每周期上传字节数限额
+	QuotaMetricsIngested   int64         `mapstructure:"quota_metrics_ingested"`   // 每周期推送指标采样数限额
+	QuotaNotificationsSent int64         `mapstructure:"quota_notifications_sent"` // 每周期邮件和短信通知数限额
+}
+
+// SetDefaults 设置用量计量默认值
+func (m *MeteringConfig) SetDefaults() {
+	viper.SetDefault("metering.enabled", true)
+	viper.SetDefault("metering.flush_interval", "10s")
+	viper.SetDefault("metering.period", MeteringPeriodMonth)
+	viper.SetDefault("metering.quota_api_calls", 0)
+	viper.SetDefault("metering.quota_storage_bytes", 0)
+	viper.SetDefault("metering.quota_metrics_ingested", 0)
+	viper.SetDefault("metering.quota_notifications_sent", 0)
+}
+
+// BindEnvs 绑定用量计量环境变量
+func (m *MeteringConfig) BindEnvs() {
+	viper.BindEnv("metering.enabled", "METERING_ENABLED")
+	viper.BindEnv("metering.flush_interval", "METERING_FLUSH_INTERVAL")
+	viper.BindEnv("metering.period", "METERING_PERIOD")
+	viper.BindEnv("metering.quota_api_calls", "METERING_QUOTA_API_CALLS")
+	viper.BindEnv("metering.quota_storage_bytes", "METERING_QUOTA_STORAGE_BYTES")
+	viper.BindEnv("metering.quota_metrics_ingested", "METERING_QUOTA_METRICS_INGESTED")
+	viper.BindEnv("metering.quota_notifications_sent", "METERING_QUOTA_NOTIFICATIONS_SENT")
+}
+
+// Validate 验证用量计量配置
+func (m *MeteringConfig) Validate() error {
+	if !m.Enabled {
+		return nil
+	}
+	if m.FlushInterval <= 0 {
+		return fmt.Errorf("用量写入间隔必须大于0")
+	}
+	if m.Period != MeteringPeriodDay && m.Period != MeteringPeriodMonth {
+		return fmt.Errorf("用量限额周期无效: %s，可选值为 day、month", m.Period)
+	}
+	if m.QuotaAPICalls < 0 || m.QuotaStorageBytes < 0 || m.QuotaMetricsIngested < 0 || m.QuotaNotificationsSent < 0 {
+		return fmt.Errorf("用量限额不能为负数")
+	}
+	return nil
+}
+
+// GetMeteringConfig 获取用量计量配置
+func GetMeteringConfig() *MeteringConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.Metering
+}
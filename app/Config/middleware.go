@@ -0,0 +1,295 @@
+package Config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// 全局中间件名称
+const (
+	MiddlewareRecovery           = "recovery"             // 错误恢复，捕获panic
+	MiddlewareCORS               = "cors"                 // 跨域请求
+	MiddlewareSecurityHeaders    = "security_headers"     // 安全响应头
+	MiddlewareDebugMode          = "debug_mode"           // 请求调试模式，需要同时启用 DEBUG_MODE_ENABLED
+	MiddlewareCompression        = "compression"          // 响应压缩，需要同时启用 COMPRESSION_ENABLED
+	MiddlewareInspector          = "inspector"            // 请求检查器，需要同时启用 INSPECTOR_ENABLED
+	MiddlewareLocale             = "locale"               // 语言解析
+	MiddlewareGateway            = "gateway"              // 网关策略，需要同时启用 GATEWAY_ENABLED
+	MiddlewareValidation         = "validation"           // 输入验证、安全检测
+	MiddlewareValidateJSON       = "validate_json"        // JSON格式验证
+	MiddlewareValidateFileUpload = "validate_file_upload" // 文件类型和大小验证
+	MiddlewareTimeout            = "timeout"              // 请求超时
+	MiddlewareRateLimit          = "rate_limit"           // 全局速率限制
+	MiddlewareResilience         = "resilience"           // 依赖熔断、路由并发限制
+	MiddlewarePerformance        = "performance"          // 性能监控
+	MiddlewareRequestStats       = "request_stats"        // 请求统计
+	MiddlewareRequestLog         = "request_log"          // 请求日志
+	MiddlewareSQLLog             = "sql_log"              // SQL日志
+	MiddlewareErrorHandling      = "error_handling"       // 业务错误处理
+	MiddlewareFaultInjection     = "fault_injection"      // 故障注入，需要同时启用 FAULT_INJECTION_ENABLED
+	MiddlewareOpenAPIValidation  = "openapi_validation"   // 按接口文档校验请求，需要配置 OPENAPI_VALIDATION
+)
+
+// MiddlewareNames 可配置的全局中间件，顺序即默认执行顺序
+var MiddlewareNames = []string{
+	MiddlewareRecovery,
+	MiddlewareCORS,
+	MiddlewareSecurityHeaders,
+	MiddlewareDebugMode,
+	MiddlewareCompression,
+	MiddlewareInspector,
+	MiddlewareLocale,
+	MiddlewareGateway,
+	MiddlewareValidation,
+	MiddlewareValidateJSON,
+	MiddlewareValidateFileUpload,
+	MiddlewareTimeout,
+	MiddlewareRateLimit,
+	MiddlewareResilience,
+	MiddlewarePerformance,
+	MiddlewareRequestStats,
+	MiddlewareRequestLog,
+	MiddlewareSQLLog,
+	MiddlewareErrorHandling,
+	MiddlewareFaultInjection,
+	MiddlewareOpenAPIValidation,
+}
+
+// MiddlewarePipelineConfig 全局中间件管道配置
+// 功能说明：
+// 1. Order 声明全局中间件的执行顺序，错误恢复中间件必须排在第一位；未列出的中间件需要在 Disabled 中显式禁用
+// 2. Environments 限制中间件只在指定的部署环境（APP_ENV）挂载，如故障注入只在预发环境挂载
+// 3. Scopes 把中间件限定到路由分组或单个路由，未配置范围的中间件对所有请求生效
+// 4. 请求ID和启动降级中间件固定在管道之前执行，不参与排序
+type MiddlewarePipelineConfig struct {
+	Order        string `mapstructure:"order"`        // 执行顺序，逗号分隔的中间件名称
+	Disabled     string `mapstructure:"disabled"`     // 禁用的中间件，逗号分隔
+	Environments string `mapstructure:"environments"` // 挂载环境，格式 "fault_injection=staging|development,sql_log=!production"
+	Scopes       string `mapstructure:"scopes"`       // 生效范围，格式 "sql_log=/api/v1/admin|!/api/v1/admin/logs,rate_limit=POST /api/v1/auth/login"
+}
+
+// MiddlewareScope 中间件生效范围
+//
+// 不带方法的路径是路由分组，按路径段匹配前缀；带方法的是单个路由，匹配路由模板（如 /api/v1/users/:id）或请求路径，
+// 方法为 * 时匹配所有方法。以 ! 开头的范围排除匹配的请求。
+type MiddlewareScope struct {
+	Method  string
+	Path    string
+	Route   bool
+	Exclude bool
+}
+
+// SetDefaults 设置中间件管道默认值
+func (m *MiddlewarePipelineConfig) SetDefaults() {
+	viper.SetDefault("middleware_pipeline.order", strings.Join(MiddlewareNames, ","))
+	viper.SetDefault("middleware_pipeline.disabled", "")
+	viper.SetDefault("middleware_pipeline.environments", "")
+	viper.SetDefault("middleware_pipeline.scopes", "")
+}
+
+// BindEnvs 绑定中间件管道环境变量
+func (m *MiddlewarePipelineConfig) BindEnvs() {
+	viper.BindEnv("middleware_pipeline.order", "MIDDLEWARE_ORDER")
+	viper.BindEnv("middleware_pipeline.disabled", "MIDDLEWARE_DISABLED")
+	viper.BindEnv("middleware_pipeline.environments", "MIDDLEWARE_ENVIRONMENTS")
+	viper.BindEnv("middleware_pipeline.scopes", "MIDDLEWARE_SCOPES")
+}
+
+// Validate 验证中间件管道配置
+func (m *MiddlewarePipelineConfig) Validate() error {
+	order, err := m.OrderList()
+	if err != nil {
+		return err
+	}
+	disabled, err := m.DisabledSet()
+	if err != nil {
+		return err
+	}
+	if disabled[MiddlewareRecovery] {
+		return fmt.Errorf("错误恢复中间件 %s 不能禁用", MiddlewareRecovery)
+	}
+	if len(order) == 0 || order[0] != MiddlewareRecovery {
+		return fmt.Errorf("错误恢复中间件 %s 必须排在第一位", MiddlewareRecovery)
+	}
+	listed := make(map[string]bool, len(order))
+	for _, name := range order {
+		listed[name] = true
+	}
+	for _, name := range MiddlewareNames {
+		if !listed[name] && !disabled[name] {
+			return fmt.Errorf("中间件 %s 未在执行顺序中列出，不需要时在 MIDDLEWARE_DISABLED 中禁用", name)
+		}
+	}
+
+	environments, err := m.ParseEnvironments()
+	if err != nil {
+		return err
+	}
+	if _, ok := environments[MiddlewareRecovery]; ok {
+		return fmt.Errorf("错误恢复中间件 %s 不能按环境挂载", MiddlewareRecovery)
+	}
+	scopes, err := m.ParseScopes()
+	if err != nil {
+		return err
+	}
+	if _, ok := scopes[MiddlewareRecovery]; ok {
+		return fmt.Errorf("错误恢复中间件 %s 不能限定范围", MiddlewareRecovery)
+	}
+	return nil
+}
+
+// OrderList 解析执行顺序，检查名称是否有效和重复
+func (m *MiddlewarePipelineConfig) OrderList() ([]string, error) {
+	var order []string
+	seen := make(map[string]bool)
+	for _, name := range splitMiddlewareList(m.Order) {
+		if !IsMiddlewareName(name) {
+			return nil, fmt.Errorf("未知的中间件: %s", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("中间件 %s 在执行顺序中重复", name)
+		}
+		seen[name] = true
+		order = append(order, name)
+	}
+	return order, nil
+}
+
+// DisabledSet 解析禁用的中间件
+func (m *MiddlewarePipelineConfig) DisabledSet() (map[string]bool, error) {
+	disabled := make(map[string]bool)
+	for _, name := range splitMiddlewareList(m.Disabled) {
+		if !IsMiddlewareName(name) {
+			return nil, fmt.Errorf("未知的中间件: %s", name)
+		}
+		disabled[name] = true
+	}
+	return disabled, nil
+}
+
+// ParseEnvironments 解析中间件的挂载环境，环境名以 ! 开头表示在该环境不挂载
+func (m *MiddlewarePipelineConfig) ParseEnvironments() (map[string][]string, error) {
+	environments := make(map[string][]string)
+	for _, item := range splitMiddlewareList(m.Environments) {
+		name, value, ok := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !IsMiddlewareName(name) {
+			return nil, fmt.Errorf("中间件挂载环境配置无效: %s", item)
+		}
+		for _, env := range strings.Split(value, "|") {
+			env = strings.TrimSpace(env)
+			if strings.TrimPrefix(env, "!") == "" {
+				return nil, fmt.Errorf("中间件挂载环境配置无效: %s", item)
+			}
+			environments[name] = append(environments[name], env)
+		}
+	}
+	return environments, nil
+}
+
+// ParseScopes 解析中间件的生效范围
+func (m *MiddlewarePipelineConfig) ParseScopes() (map[string][]MiddlewareScope, error) {
+	scopes := make(map[string][]MiddlewareScope)
+	for _, item := range splitMiddlewareList(m.Scopes) {
+		name, value, ok := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !IsMiddlewareName(name) {
+			return nil, fmt.Errorf("中间件生效范围配置无效: %s", item)
+		}
+		for _, raw := range strings.Split(value, "|") {
+			scope, err := parseMiddlewareScope(raw)
+			if err != nil {
+				return nil, fmt.Errorf("中间件 %s 的生效范围无效: %v", name, err)
+			}
+			scopes[name] = append(scopes[name], scope)
+		}
+	}
+	return scopes, nil
+}
+
+// MiddlewareEnabledIn 按挂载环境判断中间件是否在指定环境挂载，envs 为空时在所有环境挂载
+func MiddlewareEnabledIn(envs []string, environment string) bool {
+	included, hasInclude := false, false
+	for _, env := range envs {
+		if excluded, ok := strings.CutPrefix(env, "!"); ok {
+			if strings.EqualFold(excluded, environment) {
+				return false
+			}
+			continue
+		}
+		hasInclude = true
+		if strings.EqualFold(env, environment) {
+			included = true
+		}
+	}
+	return included || !hasInclude
+}
+
+// Matches 范围是否匹配请求，fullPath 为匹配到的路由模板，未匹配路由时为空
+func (s MiddlewareScope) Matches(method, fullPath, path string) bool {
+	if s.Route {
+		if s.Method != "*" && s.Method != method {
+			return false
+		}
+		return fullPath == s.Path || path == s.Path
+	}
+	return path == s.Path || strings.HasPrefix(path, s.Path+"/")
+}
+
+// IsMiddlewareName 是否为可配置的全局中间件
+func IsMiddlewareName(name string) bool {
+	for _, known := range MiddlewareNames {
+		if name == known {
+			return true
+		}
+	}
+	return false
+}
+
+// GetMiddlewarePipelineConfig 获取中间件管道配置
+func GetMiddlewarePipelineConfig() *MiddlewarePipelineConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.MiddlewarePipeline
+}
+
+// parseMiddlewareScope 解析单个生效范围，如 "/api/v1/admin"、"!/api/v1/admin/logs"、"POST /api/v1/auth/login"
+func parseMiddlewareScope(raw string) (MiddlewareScope, error) {
+	var scope MiddlewareScope
+	value := strings.TrimSpace(raw)
+	if rest, ok := strings.CutPrefix(value, "!"); ok {
+		scope.Exclude = true
+		value = strings.TrimSpace(rest)
+	}
+	if method, path, ok := strings.Cut(value, " "); ok {
+		scope.Route = true
+		scope.Method = strings.ToUpper(strings.TrimSpace(method))
+		value = strings.TrimSpace(path)
+	}
+	scope.Path = value
+	if scope.Route {
+		if scope.Path == "" || !strings.HasPrefix(scope.Path, "/") {
+			return scope, fmt.Errorf("%s", raw)
+		}
+	} else {
+		scope.Path = strings.TrimRight(scope.Path, "/")
+		if !strings.HasPrefix(value, "/") {
+			return scope, fmt.Errorf("%s", raw)
+		}
+	}
+	return scope, nil
+}
+
+// splitMiddlewareList 按逗号拆分配置并去除空项
+func splitMiddlewareList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,77 @@
+package Config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// MigrationConfig 迁移预检配置
+// 功能说明：
+// 1. 蓝绿部署期间新旧版本共用数据库，执行迁移前检查删除表/列、修改列类型等破坏性变更
+// 2. 根据表的行数估算锁表时间，超过阈值的变更标记为高风险
+// 3. 启用在线索引时按数据库改写创建索引语句（PostgreSQL 使用 CONCURRENTLY，MySQL 使用 ALGORITHM=INPLACE, LOCK=NONE）
+// 4. 生产环境执行迁移前需要先生成迁移计划并审批，审批与计划哈希绑定，待执行的迁移变化后需重新审批
+type MigrationConfig struct {
+	SafeMode        bool          `mapstructure:"safe_mode"`        // 安全模式，存在破坏性变更时拒绝执行，除非显式允许
+	RequireApproval bool          `mapstructure:"require_approval"` // 非生产环境是否也需要审批迁移计划
+	LargeTableRows  int64         `mapstructure:"large_table_rows"` // 大表行数阈值
+	RowsPerSecond   int64         `mapstructure:"rows_per_second"`  // 估算锁表时间时每秒处理的行数
+	MaxLockTime     time.Duration `mapstructure:"max_lock_time"`    // 允许的最长锁表时间，超过时标记为高风险
+	OnlineIndexes   bool          `mapstructure:"online_indexes"`   // 是否改写为在线创建索引
+	ApprovalTTL     time.Duration `mapstructure:"approval_ttl"`     // 审批有效期
+}
+
+// SetDefaults 设置迁移预检默认值
+func (m *MigrationConfig) SetDefaults() {
+	viper.SetDefault("migration.safe_mode", true)
+	viper.SetDefault("migration.require_approval", false)
+	viper.SetDefault("migration.large_table_rows", 1000000)
+	viper.SetDefault("migration.rows_per_second", 50000)
+	viper.SetDefault("migration.max_lock_time", "5s")
+	viper.SetDefault("migration.online_indexes", true)
+	viper.SetDefault("migration.approval_ttl", "24h")
+}
+
+// BindEnvs 绑定迁移预检环境变量
+func (m *MigrationConfig) BindEnvs() {
+	viper.BindEnv("migration.safe_mode", "MIGRATION_SAFE_MODE")
+	viper.BindEnv("migration.require_approval", "MIGRATION_REQUIRE_APPROVAL")
+	viper.BindEnv("migration.large_table_rows", "MIGRATION_LARGE_TABLE_ROWS")
+	viper.BindEnv("migration.rows_per_second", "MIGRATION_ROWS_PER_SECOND")
+	viper.BindEnv("migration.max_lock_time", "MIGRATION_MAX_LOCK_TIME")
+	viper.BindEnv("migration.online_indexes", "MIGRATION_ONLINE_INDEXES")
+	viper.BindEnv("migration.approval_ttl", "MIGRATION_APPROVAL_TTL")
+}
+
+// Validate 验证迁移预检配置
+func (m *MigrationConfig) Validate() error {
+	if m.LargeTableRows <= 0 {
+		return fmt.Errorf("大表行数阈值必须大于0")
+	}
+	if m.RowsPerSecond <= 0 {
+		return fmt.Errorf("每秒处理行数必须大于0")
+	}
+	if m.MaxLockTime <= 0 {
+		return fmt.Errorf("最长锁表时间必须大于0")
+	}
+	if m.ApprovalTTL <= 0 {
+		return fmt.Errorf("审批有效期必须大于0")
+	}
+	return nil
+}
+
+// ApprovalRequired 检查执行迁移前是否需要审批，生产环境始终需要
+func (m *MigrationConfig) ApprovalRequired(environment string) bool {
+	return m.RequireApproval || strings.EqualFold(environment, "production")
+}
+
+// GetMigrationConfig 获取迁移预检配置
+func GetMigrationConfig() *MigrationConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.Migration
+}
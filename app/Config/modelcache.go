@@ -0,0 +1,55 @@
+package Config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// ModelCacheConfig 热点模型缓存配置
+// 功能说明：
+// 1. 用户、角色和告警规则等几乎每个请求都会读取的模型先从进程内缓存读取，未命中时查询数据库并写入缓存
+// 2. 通过GORM写入的表在提交后使对应缓存失效；缓存只在当前实例内，其他实例的写入最多延迟 TTL 后可见
+// 3. 同一个键的并发未命中只查询一次数据库，避免缓存失效时的击穿
+type ModelCacheConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	TTL        time.Duration `mapstructure:"ttl"`         // 缓存有效期
+	MaxEntries int           `mapstructure:"max_entries"` // 最大缓存条目数，超过时淘汰最早过期的条目
+}
+
+// SetDefaults 设置模型缓存默认值
+func (m *ModelCacheConfig) SetDefaults() {
+	viper.SetDefault("model_cache.enabled", true)
+	viper.SetDefault("model_cache.ttl", "60s")
+	viper.SetDefault("model_cache.max_entries", 10000)
+}
+
+// BindEnvs 绑定模型缓存环境变量
+func (m *ModelCacheConfig) BindEnvs() {
+	viper.BindEnv("model_cache.enabled", "MODEL_CACHE_ENABLED")
+	viper.BindEnv("model_cache.ttl", "MODEL_CACHE_TTL")
+	viper.BindEnv("model_cache.max_entries", "MODEL_CACHE_MAX_ENTRIES")
+}
+
+// Validate 验证模型缓存配置，未启用时不检查
+func (m *ModelCacheConfig) Validate() error {
+	if !m.Enabled {
+		return nil
+	}
+	if m.TTL <= 0 {
+		return fmt.Errorf("模型缓存有效期必须大于0")
+	}
+	if m.MaxEntries <= 0 {
+		return fmt.Errorf("模型缓存最大条目数必须大于0")
+	}
+	return nil
+}
+
+// GetModelCacheConfig 获取模型缓存配置
+func GetModelCacheConfig() *ModelCacheConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.ModelCache
+}
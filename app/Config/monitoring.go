@@ -2,6 +2,9 @@ package Config
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -43,6 +46,7 @@ type MonitoringConfig struct {
 		ThroughputThreshold   int           `mapstructure:"throughput_threshold" json:"throughput_threshold"`
 		MemoryLeakThreshold   float64       `mapstructure:"memory_leak_threshold" json:"memory_leak_threshold"`
 		GoroutineThreshold    int           `mapstructure:"goroutine_threshold" json:"goroutine_threshold"`
+		HeapThreshold         int           `mapstructure:"heap_threshold" json:"heap_threshold"` // 堆内存阈值(MB)
 		GCThreshold           time.Duration `mapstructure:"gc_threshold" json:"gc_threshold"`
 		HTTPEnabled           bool          `mapstructure:"http_enabled" json:"http_enabled"`
 		DatabaseEnabled       bool          `mapstructure:"database_enabled" json:"database_enabled"`
@@ -71,6 +75,8 @@ type MonitoringConfig struct {
 		ConnectionThreshold  int           `mapstructure:"connection_threshold" json:"connection_threshold"`
 		EvictionThreshold    int           `mapstructure:"eviction_threshold" json:"eviction_threshold"`
 		ExpiredKeysThreshold int           `mapstructure:"expired_keys_threshold" json:"expired_keys_threshold"`
+		KeySampleSize        int           `mapstructure:"key_sample_size" json:"key_sample_size"` // 每个节点抽样统计TTL分布的键数量，0表示不统计
+		AlertCooldown        time.Duration `mapstructure:"alert_cooldown" json:"alert_cooldown"`   // 同一告警的最小间隔
 	} `mapstructure:"cache" json:"cache"`
 
 	// 业务监控配置
@@ -95,6 +101,9 @@ type MonitoringConfig struct {
 		AutoResolveDelay   time.Duration `mapstructure:"auto_resolve_delay" json:"auto_resolve_delay"`
 		SuppressionEnabled bool          `mapstructure:"suppression_enabled" json:"suppression_enabled"`
 		SuppressionWindow  time.Duration `mapstructure:"suppression_window" json:"suppression_window"`
+		ResolveStableFor   time.Duration `mapstructure:"resolve_stable_for" json:"resolve_stable_for"` // 条件持续恢复多久后才解决告警
+		FlapWindow         time.Duration `mapstructure:"flap_window" json:"flap_window"`               // 抖动检测窗口
+		FlapThreshold      int           `mapstructure:"flap_threshold" json:"flap_threshold"`         // 窗口内状态切换次数达到该值视为抖动
 	} `mapstructure:"alert" json:"alert"`
 
 	// 通知配置
@@ -134,12 +143,31 @@ type MonitoringConfig struct {
 			AtMobiles  string `mapstructure:"at_mobiles" json:"at_mobiles"`
 		} `mapstructure:"dingtalk" json:"dingtalk"`
 
+		Telegram struct {
+			Enabled      bool   `mapstructure:"enabled" json:"enabled"`
+			BotToken     string `mapstructure:"bot_token" json:"bot_token"`
+			ChatIDs      string `mapstructure:"chat_ids" json:"chat_ids"`           // 接收告警的会话ID，逗号分隔
+			APIURL       string `mapstructure:"api_url" json:"api_url"`             // Bot API地址，可指向自建Bot API服务或代理
+			ParseMode    string `mapstructure:"parse_mode" json:"parse_mode"`       // MarkdownV2 或留空发送纯文本
+			MentionUsers string `mapstructure:"mention_users" json:"mention_users"` // 告警中提及的用户名，逗号分隔
+			RateLimit    int    `mapstructure:"rate_limit" json:"rate_limit"`       // 每个会话每分钟最多发送条数
+		} `mapstructure:"telegram" json:"telegram"`
+
+		WeChatWork struct {
+			Enabled          bool   `mapstructure:"enabled" json:"enabled"`
+			WebhookURL       string `mapstructure:"webhook_url" json:"webhook_url"`
+			MsgType          string `mapstructure:"msg_type" json:"msg_type"`                   // markdown 或 text
+			MentionedList    string `mapstructure:"mentioned_list" json:"mentioned_list"`       // 提及的成员userid，逗号分隔，@all 提及所有人
+			MentionedMobiles string `mapstructure:"mentioned_mobiles" json:"mentioned_mobiles"` // 提及的成员手机号，逗号分隔，仅 text 消息支持
+			RateLimit        int    `mapstructure:"rate_limit" json:"rate_limit"`               // 每分钟最多发送条数
+		} `mapstructure:"wechat_work" json:"wechat_work"`
+
 		SMS struct {
 			Enabled      bool   `mapstructure:"enabled" json:"enabled"`
-			Provider     string `mapstructure:"provider" json:"provider"`
-			APIKey       string `mapstructure:"api_key" json:"api_key"`
-			APISecret    string `mapstructure:"api_secret" json:"api_secret"`
-			PhoneNumbers string `mapstructure:"phone_numbers" json:"phone_numbers"`
+			Provider     string `mapstructure:"provider" json:"provider"`           // 已废弃，服务商使用 SMS_DRIVER 配置
+			APIKey       string `mapstructure:"api_key" json:"api_key"`             // 已废弃，密钥使用 SMS_* 配置
+			APISecret    string `mapstructure:"api_secret" json:"api_secret"`       // 已废弃，密钥使用 SMS_* 配置
+			PhoneNumbers string `mapstructure:"phone_numbers" json:"phone_numbers"` // 接收告警的号码，逗号分隔
 		} `mapstructure:"sms" json:"sms"`
 	} `mapstructure:"notification" json:"notification"`
 
@@ -165,9 +193,254 @@ type MonitoringConfig struct {
 			KeyPrefix string        `mapstructure:"key_prefix" json:"key_prefix"`
 			TTL       time.Duration `mapstructure:"ttl" json:"ttl"`
 		} `mapstructure:"redis" json:"redis"`
+
+		// 指标历史批量写入，采样先进入缓冲队列，达到批量大小或刷新间隔时批量插入，失败时重试
+		// 启用 RedisWriteBehind 时采样先写入Redis列表（键为 Redis.KeyPrefix + metric_write_queue），由后台批量写入数据库
+		Batch struct {
+			Enabled          bool          `mapstructure:"enabled" json:"enabled"`
+			Size             int           `mapstructure:"size" json:"size"`                             // 每批插入的采样数
+			FlushInterval    time.Duration `mapstructure:"flush_interval" json:"flush_interval"`         // 未达到批量大小时的最长刷新间隔
+			MaxQueue         int           `mapstructure:"max_queue" json:"max_queue"`                   // 内存队列上限，超过时丢弃新采样并计数
+			MaxRetries       int           `mapstructure:"max_retries" json:"max_retries"`               // 单批写入失败后的重试次数
+			RetryBackoff     time.Duration `mapstructure:"retry_backoff" json:"retry_backoff"`           // 首次重试等待时间，之后每次翻倍
+			RedisWriteBehind bool          `mapstructure:"redis_write_behind" json:"redis_write_behind"` // 是否先写入Redis再异步写入数据库
+		} `mapstructure:"batch" json:"batch"`
 	} `mapstructure:"storage" json:"storage"`
+
+	// 性能剖析配置
+	// 应用监控的协程数、堆内存阈值被突破时自动采集剖析文件，保留在磁盘上供下载分析
+	Profiling struct {
+		Enabled         bool          `mapstructure:"enabled" json:"enabled"`                   // 是否开放 /debug/pprof 端点（仅管理员）
+		AutoCapture     bool          `mapstructure:"auto_capture" json:"auto_capture"`         // 是否在阈值突破时自动采集
+		CheckInterval   time.Duration `mapstructure:"check_interval" json:"check_interval"`     // 阈值检查间隔
+		CaptureCooldown time.Duration `mapstructure:"capture_cooldown" json:"capture_cooldown"` // 同一原因两次自动采集的最小间隔
+		Path            string        `mapstructure:"path" json:"path"`                         // 剖析文件保存目录
+		MaxProfiles     int           `mapstructure:"max_profiles" json:"max_profiles"`         // 保留的剖析文件数量，超出时删除最旧的
+		MaxCPUDuration  time.Duration `mapstructure:"max_cpu_duration" json:"max_cpu_duration"` // 手动采集CPU剖析的最长时间
+	} `mapstructure:"profiling" json:"profiling"`
+
+	// 外部依赖调用配置
+	// 威胁情报、Webhook、通知通道等出站HTTP请求按目标统计延迟和错误率，超过SLO时告警
+	OutboundHTTP struct {
+		Timeout               time.Duration `mapstructure:"timeout" json:"timeout"`                                   // 单次请求超时
+		MaxRetries            int           `mapstructure:"max_retries" json:"max_retries"`                           // 失败后的最大重试次数
+		RetryBackoff          time.Duration `mapstructure:"retry_backoff" json:"retry_backoff"`                       // 首次重试等待时间，之后按2倍递增
+		RetryBudgetRatio      float64       `mapstructure:"retry_budget_ratio" json:"retry_budget_ratio"`             // 统计窗口内重试次数占请求数的上限
+		Window                time.Duration `mapstructure:"window" json:"window"`                                     // SLO和熔断的统计窗口
+		LatencySLO            time.Duration `mapstructure:"latency_slo" json:"latency_slo"`                           // P95延迟目标
+		ErrorRateSLO          float64       `mapstructure:"error_rate_slo" json:"error_rate_slo"`                     // 错误率目标（百分比）
+		MinRequests           int           `mapstructure:"min_requests" json:"min_requests"`                         // 窗口内请求数达到该值才检查SLO和熔断
+		BreakerFailureRate    float64       `mapstructure:"breaker_failure_rate" json:"breaker_failure_rate"`         // 熔断的错误率阈值（百分比），0表示不熔断
+		BreakerOpenTimeout    time.Duration `mapstructure:"breaker_open_timeout" json:"breaker_open_timeout"`         // 熔断后进入半开状态前的等待时间
+		BreakerHalfOpenProbes int           `mapstructure:"breaker_half_open_probes" json:"breaker_half_open_probes"` // 半开状态允许的探测请求数
+		AlertCooldown         time.Duration `mapstructure:"alert_cooldown" json:"alert_cooldown"`                     // 同一依赖同类告警的最小间隔
+	} `mapstructure:"outbound_http" json:"outbound_http"`
+
+	// 指标采集器配置
+	// 采集器在监控核心的每轮采集中执行，采集间隔大于检查间隔时跳过未到期的轮次
+	Collectors struct {
+		DefaultTimeout time.Duration `mapstructure:"default_timeout" json:"default_timeout"` // 未单独设置超时的采集器单次采集超时
+
+		Nginx struct {
+			Enabled   bool          `mapstructure:"enabled" json:"enabled"`
+			StatusURL string        `mapstructure:"status_url" json:"status_url"` // stub_status 页面地址
+			Interval  time.Duration `mapstructure:"interval" json:"interval"`
+			Timeout   time.Duration `mapstructure:"timeout" json:"timeout"`
+		} `mapstructure:"nginx" json:"nginx"`
+
+		// TLS证书和域名到期监控，到期天数低于 Thresholds 中的各档时告警
+		Certificates struct {
+			Enabled    bool          `mapstructure:"enabled" json:"enabled"`
+			Targets    string        `mapstructure:"targets" json:"targets"`       // 检查证书的地址，格式 host[:port]，逗号分隔，默认端口443
+			Domains    string        `mapstructure:"domains" json:"domains"`       // 检查注册到期时间的域名，逗号分隔
+			Thresholds string        `mapstructure:"thresholds" json:"thresholds"` // 告警天数，逗号分隔，最小的一档为 critical
+			RDAPURL    string        `mapstructure:"rdap_url" json:"rdap_url"`     // RDAP域名查询地址前缀，域名直接拼接在后面
+			Interval   time.Duration `mapstructure:"interval" json:"interval"`
+			Timeout    time.Duration `mapstructure:"timeout" json:"timeout"`
+		} `mapstructure:"certificates" json:"certificates"`
+
+		// 容器资源指标，启动时自动识别cgroup v1/v2，识别成功后 cpu_usage、memory_usage 按容器限额计算
+		Cgroup struct {
+			Enabled bool   `mapstructure:"enabled" json:"enabled"`
+			Path    string `mapstructure:"path" json:"path"` // cgroup文件系统挂载点
+		} `mapstructure:"cgroup" json:"cgroup"`
+	} `mapstructure:"collectors" json:"collectors"`
+
+	// 外部指标推送配置
+	// 批处理任务和其他服务通过 /api/v1/monitoring/ingest 推送指标，按来源令牌认证和限额
+	Ingest struct {
+		Enabled        bool          `mapstructure:"enabled" json:"enabled"`
+		Tokens         string        `mapstructure:"tokens" json:"-"`                          // 来源令牌，格式 来源:令牌，逗号分隔
+		MaxBodySize    int64         `mapstructure:"max_body_size" json:"max_body_size"`       // 单次请求体最大字节数
+		MaxBatchSize   int           `mapstructure:"max_batch_size" json:"max_batch_size"`     // 单次请求最多采样数
+		QuotaPerMinute int           `mapstructure:"quota_per_minute" json:"quota_per_minute"` // 每个来源每分钟最多接收的采样数
+		MaxSampleAge   time.Duration `mapstructure:"max_sample_age" json:"max_sample_age"`     // 早于该时长的采样拒绝
+	} `mapstructure:"ingest" json:"ingest"`
+
+	// 外部告警接入配置
+	// Prometheus Alertmanager 等外部监控系统通过 /api/v1/monitoring/alert-webhooks 推送告警，按来源令牌认证
+	// 接入的告警与规则告警一样去重、发送通知并归入事件；通用JSON格式按 Mapping 中的字段路径取值，路径用 . 分隔
+	AlertWebhook struct {
+		Enabled      bool   `mapstructure:"enabled" json:"enabled"`
+		Tokens       string `mapstructure:"tokens" json:"-"`                    // 来源令牌，格式 来源:令牌，逗号分隔
+		MaxBodySize  int64  `mapstructure:"max_body_size" json:"max_body_size"` // 单次请求体最大字节数
+		MaxAlerts    int    `mapstructure:"max_alerts" json:"max_alerts"`       // 单次请求最多告警数
+		DefaultLevel string `mapstructure:"default_level" json:"default_level"` // 未携带或无法识别严重程度时使用的告警级别
+		Mapping      struct {
+			Alerts         string `mapstructure:"alerts" json:"alerts"`                   // 告警数组的路径，为空时请求体本身是告警数组或单个告警
+			Name           string `mapstructure:"name" json:"name"`                       // 告警名称
+			Status         string `mapstructure:"status" json:"status"`                   // 告警状态
+			Severity       string `mapstructure:"severity" json:"severity"`               // 严重程度
+			Message        string `mapstructure:"message" json:"message"`                 // 告警内容
+			Labels         string `mapstructure:"labels" json:"labels"`                   // 标签对象
+			StartsAt       string `mapstructure:"starts_at" json:"starts_at"`             // 开始时间，RFC3339或Unix秒
+			EndsAt         string `mapstructure:"ends_at" json:"ends_at"`                 // 结束时间，RFC3339或Unix秒
+			ResolvedValues string `mapstructure:"resolved_values" json:"resolved_values"` // 表示已恢复的状态值，逗号分隔，不区分大小写
+		} `mapstructure:"mapping" json:"mapping"`
+	} `mapstructure:"alert_webhook" json:"alert_webhook"`
+
+	// 指标基数保护配置
+	// 按指标名称统计最近 SeriesTTL 内出现的标签组合（序列）数，超过上限的新标签组合按 Action 拒绝或合并为 overflow="true" 序列
+	// 序列数达到上限的 WarnRatio 时产生告警，同一指标在 AlertCooldown 内只告警一次
+	Cardinality struct {
+		Enabled             bool          `mapstructure:"enabled" json:"enabled"`
+		MaxSeries           int           `mapstructure:"max_series" json:"max_series"`                         // 每个指标默认的序列数上限
+		Limits              string        `mapstructure:"limits" json:"limits"`                                 // 单独设置上限的指标，格式 指标:上限，逗号分隔
+		Action              string        `mapstructure:"action" json:"action"`                                 // 超过上限时的处理：aggregate 合并、reject 拒绝
+		MaxLabels           int           `mapstructure:"max_labels" json:"max_labels"`                         // 单个采样的标签数量上限
+		MaxLabelValueLength int           `mapstructure:"max_label_value_length" json:"max_label_value_length"` // 标签值的长度上限
+		WarnRatio           float64       `mapstructure:"warn_ratio" json:"warn_ratio"`                         // 序列数达到上限的该比例时告警
+		SeriesTTL           time.Duration `mapstructure:"series_ttl" json:"series_ttl"`                         // 超过该时长未出现的序列不再计数
+		AlertCooldown       time.Duration `mapstructure:"alert_cooldown" json:"alert_cooldown"`                 // 同一指标的告警间隔
+	} `mapstructure:"cardinality" json:"cardinality"`
+
+	// 定时监控报告配置
+	// 报告按关联的调度生成，文件保存在 StoragePath 下，超过 Retention 的历史报告由清理调度器分批清理
+	Reports struct {
+		Enabled       bool          `mapstructure:"enabled" json:"enabled"`
+		StoragePath   string        `mapstructure:"storage_path" json:"storage_path"`     // 报告文件保存目录
+		CheckInterval time.Duration `mapstructure:"check_interval" json:"check_interval"` // 检查到期调度的间隔
+		Retention     time.Duration `mapstructure:"retention" json:"retention"`           // 历史报告保留时间
+		MaxPoints     int           `mapstructure:"max_points" json:"max_points"`         // 每个图表最多的数据点数
+	} `mapstructure:"reports" json:"reports"`
+
+	// 服务等级目标配置
+	// 按评估间隔计算各SLO的达成率和错误预算消耗速率，消耗速率超过阈值时由告警引擎告警
+	// 快速消耗取1小时和5分钟窗口、慢速消耗取6小时和30分钟窗口中的较小值，两个窗口都超过阈值才告警
+	SLO struct {
+		Enabled            bool          `mapstructure:"enabled" json:"enabled"`
+		EvaluationInterval time.Duration `mapstructure:"evaluation_interval" json:"evaluation_interval"` // 评估间隔
+		FastBurnRate       float64       `mapstructure:"fast_burn_rate" json:"fast_burn_rate"`           // 快速消耗告警阈值（critical）
+		SlowBurnRate       float64       `mapstructure:"slow_burn_rate" json:"slow_burn_rate"`           // 慢速消耗告警阈值（warning）
+	} `mapstructure:"slo" json:"slo"`
+
+	// 事件（Incident）配置
+	// 告警引擎触发的告警按规则归入事件，记录状态变化、确认、评论和解决说明，用于统计MTTA/MTTR和导出复盘时间线
+	// 事件解决后 ReopenWindow 内同一规则再次触发时重新打开原事件，不创建新事件
+	Incidents struct {
+		Enabled      bool          `mapstructure:"enabled" json:"enabled"`
+		AutoResolve  bool          `mapstructure:"auto_resolve" json:"auto_resolve"`   // 事件内告警全部恢复后自动解决
+		ReopenWindow time.Duration `mapstructure:"reopen_window" json:"reopen_window"` // 重新打开已解决事件的时间窗口
+	} `mapstructure:"incidents" json:"incidents"`
+
+	// 告警静默配置
+	// 与 Alertmanager 语义相同：开始到结束时间内标签满足全部匹配器的告警不发送通知，告警本身照常触发、恢复和归入事件
+	// 维护窗口按计划自动创建关联的静默，SyncInterval 为维护窗口同步和静默缓存刷新的间隔
+	Silences struct {
+		Enabled      bool          `mapstructure:"enabled" json:"enabled"`
+		SyncInterval time.Duration `mapstructure:"sync_interval" json:"sync_interval"` // 维护窗口同步间隔
+	} `mapstructure:"silences" json:"silences"`
+
+	// Kubernetes集成配置
+	// Pod、命名空间、节点从Downward API环境变量（POD_NAME、POD_NAMESPACE、NODE_NAME、POD_IP）和服务账号目录读取，附加到指标标签和日志字段
+	// 设置 ReadinessGateType 时按同步间隔把就绪检查结果写入Pod的readiness gate条件，需要服务账号有 pods/status 的 patch 权限
+	// ExternalMetrics 中列出的指标通过 external.metrics.k8s.io 接口提供给HPA
+	Kubernetes struct {
+		Enabled               bool          `mapstructure:"enabled" json:"enabled"`
+		ServiceAccountPath    string        `mapstructure:"service_account_path" json:"service_account_path"`       // 服务账号令牌、CA证书和命名空间所在目录
+		ReadinessGateType     string        `mapstructure:"readiness_gate_type" json:"readiness_gate_type"`         // Pod readinessGates 中的条件类型，为空时不同步
+		ReadinessSyncInterval time.Duration `mapstructure:"readiness_sync_interval" json:"readiness_sync_interval"` // 就绪条件同步间隔
+		ExternalMetrics       string        `mapstructure:"external_metrics" json:"external_metrics"`               // 提供给HPA的指标名，逗号分隔，为空时不提供
+	} `mapstructure:"kubernetes" json:"kubernetes"`
+
+	// 实时指标缓冲配置
+	// 监控核心按指标在内存中保留最近 BufferSize 个采样，仪表板和健康检查优先从内存读取，缓冲区未覆盖时回退到指标历史
+	// 最近采样超过 StaleAfter 未更新时，响应的 freshness 中标记为过期
+	Realtime struct {
+		BufferSize int           `mapstructure:"buffer_size" json:"buffer_size"` // 每个指标保留的最近采样数
+		StaleAfter time.Duration `mapstructure:"stale_after" json:"stale_after"` // 指标过期时间
+	} `mapstructure:"realtime" json:"realtime"`
+
+	// 监控数据可见性配置
+	Visibility MonitoringVisibilityConfig `mapstructure:"visibility" json:"visibility"`
+}
+
+// 指标序列数超过上限时的处理方式
+const (
+	MetricCardinalityAggregate = "aggregate" // 合并为 overflow="true" 序列
+	MetricCardinalityReject    = "reject"    // 拒绝该采样
+)
+
+// 指标敏感级别，从低到高
+const (
+	MetricSensitivityPublic       = "public"       // 公开
+	MetricSensitivityInternal     = "internal"     // 内部
+	MetricSensitivityConfidential = "confidential" // 机密
+	MetricSensitivityRestricted   = "restricted"   // 受限
+)
+
+// MetricSensitivityLevels 指标敏感级别，按从低到高排列
+var MetricSensitivityLevels = []string{
+	MetricSensitivityPublic,
+	MetricSensitivityInternal,
+	MetricSensitivityConfidential,
+	MetricSensitivityRestricted,
+}
+
+// MonitoringVisibilityConfig 监控数据可见性配置
+// 指标按名称匹配敏感级别，角色的可见级别不低于指标的敏感级别时才能查看该指标及相关告警；系统管理员可以查看所有指标
+type MonitoringVisibilityConfig struct {
+	MetricSensitivity  string `mapstructure:"metric_sensitivity" json:"metric_sensitivity"`   // 指标敏感级别，格式 模式=级别，逗号分隔，模式支持 * 通配，先匹配的生效
+	RoleClearance      string `mapstructure:"role_clearance" json:"role_clearance"`           // 角色可见级别，格式 角色=级别，逗号分隔
+	DefaultSensitivity string `mapstructure:"default_sensitivity" json:"default_sensitivity"` // 未匹配任何模式的指标的敏感级别
+	DefaultClearance   string `mapstructure:"default_clearance" json:"default_clearance"`     // 未单独设置的角色的可见级别
+}
+
+// Validate 验证可见性配置
+func (c *MonitoringVisibilityConfig) Validate() error {
+	if !validMetricSensitivity(c.DefaultSensitivity) || !validMetricSensitivity(c.DefaultClearance) {
+		return fmt.Errorf("monitoring visibility default levels must be one of %s", strings.Join(MetricSensitivityLevels, ", "))
+	}
+	for _, setting := range []struct{ name, value string }{
+		{"metric sensitivity", c.MetricSensitivity},
+		{"role clearance", c.RoleClearance},
+	} {
+		for _, entry := range strings.Split(setting.value, ",") {
+			if entry = strings.TrimSpace(entry); entry == "" {
+				continue
+			}
+			key, level, found := strings.Cut(entry, "=")
+			if !found || strings.TrimSpace(key) == "" || !validMetricSensitivity(strings.TrimSpace(level)) {
+				return fmt.Errorf("invalid %s entry %q, expected name=level", setting.name, entry)
+			}
+		}
+	}
+	return nil
 }
 
+// validMetricSensitivity 是否为有效的敏感级别
+func validMetricSensitivity(level string) bool {
+	for _, item := range MetricSensitivityLevels {
+		if item == level {
+			return true
+		}
+	}
+	return false
+}
+
+// externalMetricNamePattern 提供给HPA的指标名，与监控核心的指标命名一致
+var externalMetricNamePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
 // SetDefaults 设置默认值
 func (c *MonitoringConfig) SetDefaults() {
 	// 基础配置默认值
@@ -199,6 +472,7 @@ func (c *MonitoringConfig) SetDefaults() {
 	c.ApplicationMonitoring.ThroughputThreshold = 1000
 	c.ApplicationMonitoring.MemoryLeakThreshold = 10.0
 	c.ApplicationMonitoring.GoroutineThreshold = 10000
+	c.ApplicationMonitoring.HeapThreshold = 1024 // 1GB
 	c.ApplicationMonitoring.GCThreshold = 100 * time.Millisecond
 
 	// 数据库监控默认值
@@ -219,6 +493,8 @@ func (c *MonitoringConfig) SetDefaults() {
 	c.CacheMonitoring.ConnectionThreshold = 100
 	c.CacheMonitoring.EvictionThreshold = 1000
 	c.CacheMonitoring.ExpiredKeysThreshold = 10000
+	c.CacheMonitoring.KeySampleSize = 200
+	c.CacheMonitoring.AlertCooldown = 10 * time.Minute
 
 	// 业务监控默认值
 	c.BusinessMonitoring.Enabled = true
@@ -239,6 +515,9 @@ func (c *MonitoringConfig) SetDefaults() {
 	c.AlertConfig.AutoResolveDelay = 30 * time.Minute
 	c.AlertConfig.SuppressionEnabled = true
 	c.AlertConfig.SuppressionWindow = 1 * time.Hour
+	c.AlertConfig.ResolveStableFor = 5 * time.Minute
+	c.AlertConfig.FlapWindow = 30 * time.Minute
+	c.AlertConfig.FlapThreshold = 4
 
 	// 通知配置默认值
 	c.NotificationConfig.Email.Enabled = false
@@ -256,6 +535,15 @@ func (c *MonitoringConfig) SetDefaults() {
 
 	c.NotificationConfig.DingTalk.Enabled = false
 
+	c.NotificationConfig.Telegram.Enabled = false
+	c.NotificationConfig.Telegram.APIURL = "https://api.telegram.org"
+	c.NotificationConfig.Telegram.ParseMode = "MarkdownV2"
+	c.NotificationConfig.Telegram.RateLimit = 20
+
+	c.NotificationConfig.WeChatWork.Enabled = false
+	c.NotificationConfig.WeChatWork.MsgType = "markdown"
+	c.NotificationConfig.WeChatWork.RateLimit = 20
+
 	c.NotificationConfig.SMS.Enabled = false
 
 	// 存储配置默认值
@@ -273,6 +561,128 @@ func (c *MonitoringConfig) SetDefaults() {
 	c.StorageConfig.Redis.Enabled = false
 	c.StorageConfig.Redis.KeyPrefix = "monitoring:"
 	c.StorageConfig.Redis.TTL = 24 * time.Hour
+
+	c.StorageConfig.Batch.Enabled = true
+	c.StorageConfig.Batch.Size = 500
+	c.StorageConfig.Batch.FlushInterval = 10 * time.Second
+	c.StorageConfig.Batch.MaxQueue = 20000
+	c.StorageConfig.Batch.MaxRetries = 3
+	c.StorageConfig.Batch.RetryBackoff = time.Second
+	c.StorageConfig.Batch.RedisWriteBehind = false
+
+	// 性能剖析默认值
+	c.Profiling.Enabled = true
+	c.Profiling.AutoCapture = true
+	c.Profiling.CheckInterval = 30 * time.Second
+	c.Profiling.CaptureCooldown = 10 * time.Minute
+	c.Profiling.Path = "storage/profiles"
+	c.Profiling.MaxProfiles = 20
+	c.Profiling.MaxCPUDuration = 20 * time.Second // 需小于全局30秒请求超时
+
+	// 外部依赖调用默认值
+	c.OutboundHTTP.Timeout = 10 * time.Second
+	c.OutboundHTTP.MaxRetries = 2
+	c.OutboundHTTP.RetryBackoff = 200 * time.Millisecond
+	c.OutboundHTTP.RetryBudgetRatio = 0.2
+	c.OutboundHTTP.Window = 5 * time.Minute
+	c.OutboundHTTP.LatencySLO = 2 * time.Second
+	c.OutboundHTTP.ErrorRateSLO = 5.0
+	c.OutboundHTTP.MinRequests = 20
+	c.OutboundHTTP.BreakerFailureRate = 50.0
+	c.OutboundHTTP.BreakerOpenTimeout = 30 * time.Second
+	c.OutboundHTTP.BreakerHalfOpenProbes = 1
+	c.OutboundHTTP.AlertCooldown = 10 * time.Minute
+
+	// 指标采集器默认值
+	c.Collectors.DefaultTimeout = 10 * time.Second
+	c.Collectors.Nginx.Enabled = false
+	c.Collectors.Nginx.StatusURL = "http://127.0.0.1/nginx_status"
+	c.Collectors.Nginx.Interval = 30 * time.Second
+	c.Collectors.Nginx.Timeout = 5 * time.Second
+	c.Collectors.Cgroup.Enabled = true
+	c.Collectors.Cgroup.Path = "/sys/fs/cgroup"
+	c.Collectors.Certificates.Enabled = false
+	c.Collectors.Certificates.Targets = ""
+	c.Collectors.Certificates.Domains = ""
+	c.Collectors.Certificates.Thresholds = "30,14,7"
+	c.Collectors.Certificates.RDAPURL = "https://rdap.org/domain/"
+	c.Collectors.Certificates.Interval = 6 * time.Hour
+	c.Collectors.Certificates.Timeout = 30 * time.Second
+
+	// 外部指标推送默认值
+	c.Ingest.Enabled = false
+	c.Ingest.Tokens = ""
+	c.Ingest.MaxBodySize = 5 << 20
+	c.Ingest.MaxBatchSize = 5000
+	c.Ingest.QuotaPerMinute = 60000
+	c.Ingest.MaxSampleAge = time.Hour
+
+	// 外部告警接入默认值
+	c.AlertWebhook.Enabled = false
+	c.AlertWebhook.Tokens = ""
+	c.AlertWebhook.MaxBodySize = 1 << 20
+	c.AlertWebhook.MaxAlerts = 500
+	c.AlertWebhook.DefaultLevel = "warning"
+	c.AlertWebhook.Mapping.Alerts = ""
+	c.AlertWebhook.Mapping.Name = "name"
+	c.AlertWebhook.Mapping.Status = "status"
+	c.AlertWebhook.Mapping.Severity = "severity"
+	c.AlertWebhook.Mapping.Message = "message"
+	c.AlertWebhook.Mapping.Labels = "labels"
+	c.AlertWebhook.Mapping.StartsAt = "starts_at"
+	c.AlertWebhook.Mapping.EndsAt = "ends_at"
+	c.AlertWebhook.Mapping.ResolvedValues = "resolved,ok,closed,recovered"
+
+	// 指标基数保护默认值
+	c.Cardinality.Enabled = true
+	c.Cardinality.MaxSeries = 1000
+	c.Cardinality.Limits = ""
+	c.Cardinality.Action = MetricCardinalityAggregate
+	c.Cardinality.MaxLabels = 20
+	c.Cardinality.MaxLabelValueLength = 128
+	c.Cardinality.WarnRatio = 0.8
+	c.Cardinality.SeriesTTL = time.Hour
+	c.Cardinality.AlertCooldown = 30 * time.Minute
+
+	// 定时监控报告默认值
+	c.Reports.Enabled = true
+	c.Reports.StoragePath = "./storage/reports"
+	c.Reports.CheckInterval = time.Minute
+	c.Reports.Retention = 90 * 24 * time.Hour // 90天
+	c.Reports.MaxPoints = 200
+
+	// 服务等级目标默认值
+	// 30天窗口下14.4倍速率约2天耗尽预算（1小时消耗2%），6倍速率约5天耗尽（6小时消耗5%）
+	c.SLO.Enabled = true
+	c.SLO.EvaluationInterval = time.Minute
+	c.SLO.FastBurnRate = 14.4
+	c.SLO.SlowBurnRate = 6
+
+	// 事件默认值
+	c.Incidents.Enabled = true
+	c.Incidents.AutoResolve = true
+	c.Incidents.ReopenWindow = 30 * time.Minute
+
+	// 告警静默默认值
+	c.Silences.Enabled = true
+	c.Silences.SyncInterval = time.Minute
+
+	// Kubernetes集成默认值
+	c.Kubernetes.Enabled = false
+	c.Kubernetes.ServiceAccountPath = "/var/run/secrets/kubernetes.io/serviceaccount"
+	c.Kubernetes.ReadinessGateType = ""
+	c.Kubernetes.ReadinessSyncInterval = 10 * time.Second
+	c.Kubernetes.ExternalMetrics = ""
+
+	// 实时指标缓冲默认值，按30秒采集间隔约保留3小时
+	c.Realtime.BufferSize = 360
+	c.Realtime.StaleAfter = 90 * time.Second
+
+	// 监控数据可见性默认值，未配置敏感指标时所有登录用户都可以查看
+	c.Visibility.MetricSensitivity = ""
+	c.Visibility.RoleClearance = ""
+	c.Visibility.DefaultSensitivity = MetricSensitivityInternal
+	c.Visibility.DefaultClearance = MetricSensitivityInternal
 }
 
 // BindEnvs 绑定环境变量
@@ -305,6 +715,7 @@ func (c *MonitoringConfig) BindEnvs() {
 	viper.SetDefault("MONITORING_APP_THROUGHPUT_THRESHOLD", c.ApplicationMonitoring.ThroughputThreshold)
 	viper.SetDefault("MONITORING_APP_MEMORY_LEAK_THRESHOLD", c.ApplicationMonitoring.MemoryLeakThreshold)
 	viper.SetDefault("MONITORING_APP_GOROUTINE_THRESHOLD", c.ApplicationMonitoring.GoroutineThreshold)
+	viper.SetDefault("MONITORING_APP_HEAP_THRESHOLD", c.ApplicationMonitoring.HeapThreshold)
 	viper.SetDefault("MONITORING_APP_GC_THRESHOLD", c.ApplicationMonitoring.GCThreshold)
 
 	// 数据库监控环境变量
@@ -325,6 +736,8 @@ func (c *MonitoringConfig) BindEnvs() {
 	viper.SetDefault("MONITORING_CACHE_CONNECTION_THRESHOLD", c.CacheMonitoring.ConnectionThreshold)
 	viper.SetDefault("MONITORING_CACHE_EVICTION_THRESHOLD", c.CacheMonitoring.EvictionThreshold)
 	viper.SetDefault("MONITORING_CACHE_EXPIRED_KEYS_THRESHOLD", c.CacheMonitoring.ExpiredKeysThreshold)
+	viper.SetDefault("MONITORING_CACHE_KEY_SAMPLE_SIZE", c.CacheMonitoring.KeySampleSize)
+	viper.SetDefault("MONITORING_CACHE_ALERT_COOLDOWN", c.CacheMonitoring.AlertCooldown)
 
 	// 业务监控环境变量
 	viper.SetDefault("MONITORING_BUSINESS_ENABLED", c.BusinessMonitoring.Enabled)
@@ -345,6 +758,9 @@ func (c *MonitoringConfig) BindEnvs() {
 	viper.SetDefault("MONITORING_ALERT_AUTO_RESOLVE_DELAY", c.AlertConfig.AutoResolveDelay)
 	viper.SetDefault("MONITORING_ALERT_SUPPRESSION_ENABLED", c.AlertConfig.SuppressionEnabled)
 	viper.SetDefault("MONITORING_ALERT_SUPPRESSION_WINDOW", c.AlertConfig.SuppressionWindow)
+	viper.SetDefault("MONITORING_ALERT_RESOLVE_STABLE_FOR", c.AlertConfig.ResolveStableFor)
+	viper.SetDefault("MONITORING_ALERT_FLAP_WINDOW", c.AlertConfig.FlapWindow)
+	viper.SetDefault("MONITORING_ALERT_FLAP_THRESHOLD", c.AlertConfig.FlapThreshold)
 
 	// 通知配置环境变量
 	viper.SetDefault("MONITORING_NOTIFICATION_EMAIL_ENABLED", c.NotificationConfig.Email.Enabled)
@@ -374,6 +790,21 @@ func (c *MonitoringConfig) BindEnvs() {
 	viper.SetDefault("MONITORING_NOTIFICATION_DINGTALK_SECRET", c.NotificationConfig.DingTalk.Secret)
 	viper.SetDefault("MONITORING_NOTIFICATION_DINGTALK_AT_MOBILES", c.NotificationConfig.DingTalk.AtMobiles)
 
+	viper.SetDefault("MONITORING_NOTIFICATION_TELEGRAM_ENABLED", c.NotificationConfig.Telegram.Enabled)
+	viper.SetDefault("MONITORING_NOTIFICATION_TELEGRAM_BOT_TOKEN", c.NotificationConfig.Telegram.BotToken)
+	viper.SetDefault("MONITORING_NOTIFICATION_TELEGRAM_CHAT_IDS", c.NotificationConfig.Telegram.ChatIDs)
+	viper.SetDefault("MONITORING_NOTIFICATION_TELEGRAM_API_URL", c.NotificationConfig.Telegram.APIURL)
+	viper.SetDefault("MONITORING_NOTIFICATION_TELEGRAM_PARSE_MODE", c.NotificationConfig.Telegram.ParseMode)
+	viper.SetDefault("MONITORING_NOTIFICATION_TELEGRAM_MENTION_USERS", c.NotificationConfig.Telegram.MentionUsers)
+	viper.SetDefault("MONITORING_NOTIFICATION_TELEGRAM_RATE_LIMIT", c.NotificationConfig.Telegram.RateLimit)
+
+	viper.SetDefault("MONITORING_NOTIFICATION_WECHAT_WORK_ENABLED", c.NotificationConfig.WeChatWork.Enabled)
+	viper.SetDefault("MONITORING_NOTIFICATION_WECHAT_WORK_WEBHOOK_URL", c.NotificationConfig.WeChatWork.WebhookURL)
+	viper.SetDefault("MONITORING_NOTIFICATION_WECHAT_WORK_MSG_TYPE", c.NotificationConfig.WeChatWork.MsgType)
+	viper.SetDefault("MONITORING_NOTIFICATION_WECHAT_WORK_MENTIONED_LIST", c.NotificationConfig.WeChatWork.MentionedList)
+	viper.SetDefault("MONITORING_NOTIFICATION_WECHAT_WORK_MENTIONED_MOBILES", c.NotificationConfig.WeChatWork.MentionedMobiles)
+	viper.SetDefault("MONITORING_NOTIFICATION_WECHAT_WORK_RATE_LIMIT", c.NotificationConfig.WeChatWork.RateLimit)
+
 	viper.SetDefault("MONITORING_NOTIFICATION_SMS_ENABLED", c.NotificationConfig.SMS.Enabled)
 	viper.SetDefault("MONITORING_NOTIFICATION_SMS_PROVIDER", c.NotificationConfig.SMS.Provider)
 	viper.SetDefault("MONITORING_NOTIFICATION_SMS_API_KEY", c.NotificationConfig.SMS.APIKey)
@@ -393,6 +824,126 @@ func (c *MonitoringConfig) BindEnvs() {
 	viper.SetDefault("MONITORING_STORAGE_REDIS_ENABLED", c.StorageConfig.Redis.Enabled)
 	viper.SetDefault("MONITORING_STORAGE_REDIS_KEY_PREFIX", c.StorageConfig.Redis.KeyPrefix)
 	viper.SetDefault("MONITORING_STORAGE_REDIS_TTL", c.StorageConfig.Redis.TTL)
+	viper.SetDefault("MONITORING_STORAGE_BATCH_ENABLED", c.StorageConfig.Batch.Enabled)
+	viper.SetDefault("MONITORING_STORAGE_BATCH_SIZE", c.StorageConfig.Batch.Size)
+	viper.SetDefault("MONITORING_STORAGE_BATCH_FLUSH_INTERVAL", c.StorageConfig.Batch.FlushInterval)
+	viper.SetDefault("MONITORING_STORAGE_BATCH_MAX_QUEUE", c.StorageConfig.Batch.MaxQueue)
+	viper.SetDefault("MONITORING_STORAGE_BATCH_MAX_RETRIES", c.StorageConfig.Batch.MaxRetries)
+	viper.SetDefault("MONITORING_STORAGE_BATCH_RETRY_BACKOFF", c.StorageConfig.Batch.RetryBackoff)
+	viper.SetDefault("MONITORING_STORAGE_BATCH_REDIS_WRITE_BEHIND", c.StorageConfig.Batch.RedisWriteBehind)
+
+	// 性能剖析环境变量
+	viper.SetDefault("MONITORING_PROFILING_ENABLED", c.Profiling.Enabled)
+	viper.SetDefault("MONITORING_PROFILING_AUTO_CAPTURE", c.Profiling.AutoCapture)
+	viper.SetDefault("MONITORING_PROFILING_CHECK_INTERVAL", c.Profiling.CheckInterval)
+	viper.SetDefault("MONITORING_PROFILING_CAPTURE_COOLDOWN", c.Profiling.CaptureCooldown)
+	viper.SetDefault("MONITORING_PROFILING_PATH", c.Profiling.Path)
+	viper.SetDefault("MONITORING_PROFILING_MAX_PROFILES", c.Profiling.MaxProfiles)
+	viper.SetDefault("MONITORING_PROFILING_MAX_CPU_DURATION", c.Profiling.MaxCPUDuration)
+
+	// 外部依赖调用环境变量
+	viper.SetDefault("MONITORING_OUTBOUND_TIMEOUT", c.OutboundHTTP.Timeout)
+	viper.SetDefault("MONITORING_OUTBOUND_MAX_RETRIES", c.OutboundHTTP.MaxRetries)
+	viper.SetDefault("MONITORING_OUTBOUND_RETRY_BACKOFF", c.OutboundHTTP.RetryBackoff)
+	viper.SetDefault("MONITORING_OUTBOUND_RETRY_BUDGET_RATIO", c.OutboundHTTP.RetryBudgetRatio)
+	viper.SetDefault("MONITORING_OUTBOUND_WINDOW", c.OutboundHTTP.Window)
+	viper.SetDefault("MONITORING_OUTBOUND_LATENCY_SLO", c.OutboundHTTP.LatencySLO)
+	viper.SetDefault("MONITORING_OUTBOUND_ERROR_RATE_SLO", c.OutboundHTTP.ErrorRateSLO)
+	viper.SetDefault("MONITORING_OUTBOUND_MIN_REQUESTS", c.OutboundHTTP.MinRequests)
+	viper.SetDefault("MONITORING_OUTBOUND_BREAKER_FAILURE_RATE", c.OutboundHTTP.BreakerFailureRate)
+	viper.SetDefault("MONITORING_OUTBOUND_BREAKER_OPEN_TIMEOUT", c.OutboundHTTP.BreakerOpenTimeout)
+	viper.SetDefault("MONITORING_OUTBOUND_BREAKER_HALF_OPEN_PROBES", c.OutboundHTTP.BreakerHalfOpenProbes)
+	viper.SetDefault("MONITORING_OUTBOUND_ALERT_COOLDOWN", c.OutboundHTTP.AlertCooldown)
+
+	// 指标采集器环境变量
+	viper.SetDefault("MONITORING_COLLECTORS_DEFAULT_TIMEOUT", c.Collectors.DefaultTimeout)
+	viper.SetDefault("MONITORING_COLLECTORS_NGINX_ENABLED", c.Collectors.Nginx.Enabled)
+	viper.SetDefault("MONITORING_COLLECTORS_NGINX_STATUS_URL", c.Collectors.Nginx.StatusURL)
+	viper.SetDefault("MONITORING_COLLECTORS_NGINX_INTERVAL", c.Collectors.Nginx.Interval)
+	viper.SetDefault("MONITORING_COLLECTORS_NGINX_TIMEOUT", c.Collectors.Nginx.Timeout)
+	viper.SetDefault("MONITORING_COLLECTORS_CGROUP_ENABLED", c.Collectors.Cgroup.Enabled)
+	viper.SetDefault("MONITORING_COLLECTORS_CGROUP_PATH", c.Collectors.Cgroup.Path)
+	viper.SetDefault("MONITORING_COLLECTORS_CERTIFICATES_ENABLED", c.Collectors.Certificates.Enabled)
+	viper.SetDefault("MONITORING_COLLECTORS_CERTIFICATES_TARGETS", c.Collectors.Certificates.Targets)
+	viper.SetDefault("MONITORING_COLLECTORS_CERTIFICATES_DOMAINS", c.Collectors.Certificates.Domains)
+	viper.SetDefault("MONITORING_COLLECTORS_CERTIFICATES_THRESHOLDS", c.Collectors.Certificates.Thresholds)
+	viper.SetDefault("MONITORING_COLLECTORS_CERTIFICATES_RDAP_URL", c.Collectors.Certificates.RDAPURL)
+	viper.SetDefault("MONITORING_COLLECTORS_CERTIFICATES_INTERVAL", c.Collectors.Certificates.Interval)
+	viper.SetDefault("MONITORING_COLLECTORS_CERTIFICATES_TIMEOUT", c.Collectors.Certificates.Timeout)
+
+	// 外部指标推送环境变量
+	viper.SetDefault("MONITORING_INGEST_ENABLED", c.Ingest.Enabled)
+	viper.SetDefault("MONITORING_INGEST_TOKENS", c.Ingest.Tokens)
+	viper.SetDefault("MONITORING_INGEST_MAX_BODY_SIZE", c.Ingest.MaxBodySize)
+	viper.SetDefault("MONITORING_INGEST_MAX_BATCH_SIZE", c.Ingest.MaxBatchSize)
+	viper.SetDefault("MONITORING_INGEST_QUOTA_PER_MINUTE", c.Ingest.QuotaPerMinute)
+	viper.SetDefault("MONITORING_INGEST_MAX_SAMPLE_AGE", c.Ingest.MaxSampleAge)
+
+	// 外部告警接入环境变量
+	viper.SetDefault("MONITORING_ALERT_WEBHOOK_ENABLED", c.AlertWebhook.Enabled)
+	viper.SetDefault("MONITORING_ALERT_WEBHOOK_TOKENS", c.AlertWebhook.Tokens)
+	viper.SetDefault("MONITORING_ALERT_WEBHOOK_MAX_BODY_SIZE", c.AlertWebhook.MaxBodySize)
+	viper.SetDefault("MONITORING_ALERT_WEBHOOK_MAX_ALERTS", c.AlertWebhook.MaxAlerts)
+	viper.SetDefault("MONITORING_ALERT_WEBHOOK_DEFAULT_LEVEL", c.AlertWebhook.DefaultLevel)
+	viper.SetDefault("MONITORING_ALERT_WEBHOOK_MAPPING_ALERTS", c.AlertWebhook.Mapping.Alerts)
+	viper.SetDefault("MONITORING_ALERT_WEBHOOK_MAPPING_NAME", c.AlertWebhook.Mapping.Name)
+	viper.SetDefault("MONITORING_ALERT_WEBHOOK_MAPPING_STATUS", c.AlertWebhook.Mapping.Status)
+	viper.SetDefault("MONITORING_ALERT_WEBHOOK_MAPPING_SEVERITY", c.AlertWebhook.Mapping.Severity)
+	viper.SetDefault("MONITORING_ALERT_WEBHOOK_MAPPING_MESSAGE", c.AlertWebhook.Mapping.Message)
+	viper.SetDefault("MONITORING_ALERT_WEBHOOK_MAPPING_LABELS", c.AlertWebhook.Mapping.Labels)
+	viper.SetDefault("MONITORING_ALERT_WEBHOOK_MAPPING_STARTS_AT", c.AlertWebhook.Mapping.StartsAt)
+	viper.SetDefault("MONITORING_ALERT_WEBHOOK_MAPPING_ENDS_AT", c.AlertWebhook.Mapping.EndsAt)
+	viper.SetDefault("MONITORING_ALERT_WEBHOOK_MAPPING_RESOLVED_VALUES", c.AlertWebhook.Mapping.ResolvedValues)
+
+	// 指标基数保护环境变量
+	viper.SetDefault("MONITORING_CARDINALITY_ENABLED", c.Cardinality.Enabled)
+	viper.SetDefault("MONITORING_CARDINALITY_MAX_SERIES", c.Cardinality.MaxSeries)
+	viper.SetDefault("MONITORING_CARDINALITY_LIMITS", c.Cardinality.Limits)
+	viper.SetDefault("MONITORING_CARDINALITY_ACTION", c.Cardinality.Action)
+	viper.SetDefault("MONITORING_CARDINALITY_MAX_LABELS", c.Cardinality.MaxLabels)
+	viper.SetDefault("MONITORING_CARDINALITY_MAX_LABEL_VALUE_LENGTH", c.Cardinality.MaxLabelValueLength)
+	viper.SetDefault("MONITORING_CARDINALITY_WARN_RATIO", c.Cardinality.WarnRatio)
+	viper.SetDefault("MONITORING_CARDINALITY_SERIES_TTL", c.Cardinality.SeriesTTL)
+	viper.SetDefault("MONITORING_CARDINALITY_ALERT_COOLDOWN", c.Cardinality.AlertCooldown)
+
+	// 定时监控报告环境变量
+	viper.SetDefault("MONITORING_REPORTS_ENABLED", c.Reports.Enabled)
+	viper.SetDefault("MONITORING_REPORTS_STORAGE_PATH", c.Reports.StoragePath)
+	viper.SetDefault("MONITORING_REPORTS_CHECK_INTERVAL", c.Reports.CheckInterval)
+	viper.SetDefault("MONITORING_REPORTS_RETENTION", c.Reports.Retention)
+	viper.SetDefault("MONITORING_REPORTS_MAX_POINTS", c.Reports.MaxPoints)
+
+	// 服务等级目标环境变量
+	viper.SetDefault("MONITORING_SLO_ENABLED", c.SLO.Enabled)
+	viper.SetDefault("MONITORING_SLO_EVALUATION_INTERVAL", c.SLO.EvaluationInterval)
+	viper.SetDefault("MONITORING_SLO_FAST_BURN_RATE", c.SLO.FastBurnRate)
+	viper.SetDefault("MONITORING_SLO_SLOW_BURN_RATE", c.SLO.SlowBurnRate)
+
+	// 事件环境变量
+	viper.SetDefault("MONITORING_INCIDENTS_ENABLED", c.Incidents.Enabled)
+	viper.SetDefault("MONITORING_INCIDENTS_AUTO_RESOLVE", c.Incidents.AutoResolve)
+	viper.SetDefault("MONITORING_INCIDENTS_REOPEN_WINDOW", c.Incidents.ReopenWindow)
+
+	// 告警静默环境变量
+	viper.SetDefault("MONITORING_SILENCES_ENABLED", c.Silences.Enabled)
+	viper.SetDefault("MONITORING_SILENCES_SYNC_INTERVAL", c.Silences.SyncInterval)
+
+	// Kubernetes集成环境变量
+	viper.SetDefault("MONITORING_KUBERNETES_ENABLED", c.Kubernetes.Enabled)
+	viper.SetDefault("MONITORING_KUBERNETES_SERVICE_ACCOUNT_PATH", c.Kubernetes.ServiceAccountPath)
+	viper.SetDefault("MONITORING_KUBERNETES_READINESS_GATE_TYPE", c.Kubernetes.ReadinessGateType)
+	viper.SetDefault("MONITORING_KUBERNETES_READINESS_SYNC_INTERVAL", c.Kubernetes.ReadinessSyncInterval)
+	viper.SetDefault("MONITORING_KUBERNETES_EXTERNAL_METRICS", c.Kubernetes.ExternalMetrics)
+
+	// 实时指标缓冲环境变量
+	viper.SetDefault("MONITORING_REALTIME_BUFFER_SIZE", c.Realtime.BufferSize)
+	viper.SetDefault("MONITORING_REALTIME_STALE_AFTER", c.Realtime.StaleAfter)
+
+	// 监控数据可见性环境变量
+	viper.BindEnv("monitoring.visibility.metric_sensitivity", "MONITORING_VISIBILITY_METRIC_SENSITIVITY")
+	viper.BindEnv("monitoring.visibility.role_clearance", "MONITORING_VISIBILITY_ROLE_CLEARANCE")
+	viper.BindEnv("monitoring.visibility.default_sensitivity", "MONITORING_VISIBILITY_DEFAULT_SENSITIVITY")
+	viper.BindEnv("monitoring.visibility.default_clearance", "MONITORING_VISIBILITY_DEFAULT_CLEARANCE")
 }
 
 // Validate 验证配置
@@ -430,6 +981,208 @@ func (c *MonitoringConfig) Validate() error {
 		return fmt.Errorf("throughput threshold must be positive")
 	}
 
+	// 性能剖析验证
+	if c.Profiling.AutoCapture {
+		if c.Profiling.CheckInterval < time.Second {
+			return fmt.Errorf("profiling check interval must be at least 1 second")
+		}
+		if c.Profiling.MaxProfiles <= 0 {
+			return fmt.Errorf("max profiles must be positive when auto capture is enabled")
+		}
+	}
+
+	// 外部依赖调用验证
+	if c.OutboundHTTP.Timeout <= 0 {
+		return fmt.Errorf("outbound timeout must be positive")
+	}
+	if c.OutboundHTTP.MaxRetries < 0 {
+		return fmt.Errorf("outbound max retries must not be negative")
+	}
+	if c.OutboundHTTP.Window < time.Second {
+		return fmt.Errorf("outbound window must be at least 1s")
+	}
+	if c.OutboundHTTP.BreakerFailureRate < 0 || c.OutboundHTTP.BreakerFailureRate > 100 {
+		return fmt.Errorf("outbound breaker failure rate must be between 0 and 100")
+	}
+
+	// 指标采集器验证
+	if c.Collectors.DefaultTimeout <= 0 {
+		return fmt.Errorf("collector default timeout must be positive")
+	}
+	if c.Collectors.Nginx.Enabled {
+		if c.Collectors.Nginx.StatusURL == "" {
+			return fmt.Errorf("nginx status URL is required when nginx collector is enabled")
+		}
+		if c.Collectors.Nginx.Interval < 0 || c.Collectors.Nginx.Timeout < 0 {
+			return fmt.Errorf("nginx collector interval and timeout must not be negative")
+		}
+	}
+	if certificates := c.Collectors.Certificates; certificates.Enabled {
+		if strings.TrimSpace(certificates.Targets) == "" && strings.TrimSpace(certificates.Domains) == "" {
+			return fmt.Errorf("certificate targets or domains are required when certificate collector is enabled")
+		}
+		thresholds := strings.Split(certificates.Thresholds, ",")
+		for _, threshold := range thresholds {
+			if days, err := strconv.Atoi(strings.TrimSpace(threshold)); err != nil || days <= 0 {
+				return fmt.Errorf("invalid certificate expiry threshold: %q", threshold)
+			}
+		}
+		if strings.TrimSpace(certificates.Domains) != "" && !strings.HasPrefix(certificates.RDAPURL, "http://") && !strings.HasPrefix(certificates.RDAPURL, "https://") {
+			return fmt.Errorf("RDAP URL must be an http or https URL")
+		}
+		if certificates.Interval < 0 || certificates.Timeout < 0 {
+			return fmt.Errorf("certificate collector interval and timeout must not be negative")
+		}
+	}
+	if c.Collectors.Cgroup.Enabled && c.Collectors.Cgroup.Path == "" {
+		return fmt.Errorf("cgroup path is required when cgroup collector is enabled")
+	}
+
+	// 外部指标推送验证
+	if c.Ingest.Enabled {
+		if strings.TrimSpace(c.Ingest.Tokens) == "" {
+			return fmt.Errorf("ingest tokens are required when metric ingestion is enabled")
+		}
+		for _, entry := range strings.Split(c.Ingest.Tokens, ",") {
+			if entry = strings.TrimSpace(entry); entry == "" {
+				continue
+			}
+			source, token, found := strings.Cut(entry, ":")
+			if !found || strings.TrimSpace(source) == "" || strings.TrimSpace(token) == "" {
+				return fmt.Errorf("invalid ingest token entry %q, expected source:token", entry)
+			}
+		}
+		if c.Ingest.MaxBodySize <= 0 || c.Ingest.MaxBatchSize <= 0 || c.Ingest.QuotaPerMinute <= 0 {
+			return fmt.Errorf("ingest body size, batch size and quota must be positive")
+		}
+		if c.Ingest.MaxSampleAge <= 0 {
+			return fmt.Errorf("ingest max sample age must be positive")
+		}
+	}
+
+	// 外部告警接入验证
+	if c.AlertWebhook.Enabled {
+		if strings.TrimSpace(c.AlertWebhook.Tokens) == "" {
+			return fmt.Errorf("alert webhook tokens are required when alert webhook is enabled")
+		}
+		for _, entry := range strings.Split(c.AlertWebhook.Tokens, ",") {
+			if entry = strings.TrimSpace(entry); entry == "" {
+				continue
+			}
+			source, token, found := strings.Cut(entry, ":")
+			if !found || strings.TrimSpace(source) == "" || strings.TrimSpace(token) == "" {
+				return fmt.Errorf("invalid alert webhook token entry %q, expected source:token", entry)
+			}
+		}
+		if c.AlertWebhook.MaxBodySize <= 0 || c.AlertWebhook.MaxAlerts <= 0 {
+			return fmt.Errorf("alert webhook body size and max alerts must be positive")
+		}
+		switch c.AlertWebhook.DefaultLevel {
+		case "info", "warning", "error", "critical":
+		default:
+			return fmt.Errorf("invalid alert webhook default level %q, expected info, warning, error or critical", c.AlertWebhook.DefaultLevel)
+		}
+		if strings.TrimSpace(c.AlertWebhook.Mapping.Name) == "" {
+			return fmt.Errorf("alert webhook mapping name is required")
+		}
+	}
+
+	// 指标基数保护验证
+	if c.Cardinality.Enabled {
+		if c.Cardinality.MaxSeries <= 0 || c.Cardinality.MaxLabels <= 0 || c.Cardinality.MaxLabelValueLength <= 0 {
+			return fmt.Errorf("cardinality max series, max labels and max label value length must be positive")
+		}
+		if c.Cardinality.Action != MetricCardinalityAggregate && c.Cardinality.Action != MetricCardinalityReject {
+			return fmt.Errorf("invalid cardinality action %q, expected aggregate or reject", c.Cardinality.Action)
+		}
+		for _, entry := range strings.Split(c.Cardinality.Limits, ",") {
+			if entry = strings.TrimSpace(entry); entry == "" {
+				continue
+			}
+			metric, limit, found := strings.Cut(entry, ":")
+			if value, err := strconv.Atoi(strings.TrimSpace(limit)); !found || strings.TrimSpace(metric) == "" || err != nil || value <= 0 {
+				return fmt.Errorf("invalid cardinality limit entry %q, expected metric:limit", entry)
+			}
+		}
+		if c.Cardinality.WarnRatio <= 0 || c.Cardinality.WarnRatio > 1 {
+			return fmt.Errorf("cardinality warn ratio must be between 0 and 1")
+		}
+		if c.Cardinality.SeriesTTL <= 0 || c.Cardinality.AlertCooldown < 0 {
+			return fmt.Errorf("cardinality series TTL must be positive and alert cooldown must not be negative")
+		}
+	}
+
+	// 定时监控报告验证
+	if c.Reports.Enabled {
+		if strings.TrimSpace(c.Reports.StoragePath) == "" {
+			return fmt.Errorf("report storage path is required when reports are enabled")
+		}
+		if c.Reports.CheckInterval <= 0 || c.Reports.Retention <= 0 {
+			return fmt.Errorf("report check interval and retention must be positive")
+		}
+		if c.Reports.MaxPoints < 10 {
+			return fmt.Errorf("report max points must be at least 10")
+		}
+	}
+
+	// 服务等级目标验证
+	if c.SLO.Enabled {
+		if c.SLO.EvaluationInterval <= 0 {
+			return fmt.Errorf("SLO evaluation interval must be positive")
+		}
+		if c.SLO.FastBurnRate <= 0 || c.SLO.SlowBurnRate <= 0 {
+			return fmt.Errorf("SLO burn rate thresholds must be positive")
+		}
+	}
+
+	// 事件验证
+	if c.Incidents.Enabled && c.Incidents.ReopenWindow < 0 {
+		return fmt.Errorf("incident reopen window must not be negative")
+	}
+
+	// 告警静默验证
+	if c.Silences.Enabled && c.Silences.SyncInterval <= 0 {
+		return fmt.Errorf("silence sync interval must be positive")
+	}
+
+	// Kubernetes集成验证
+	if c.Kubernetes.Enabled {
+		if c.Kubernetes.ReadinessGateType != "" && c.Kubernetes.ReadinessSyncInterval <= 0 {
+			return fmt.Errorf("kubernetes readiness sync interval must be positive")
+		}
+		for _, name := range strings.Split(c.Kubernetes.ExternalMetrics, ",") {
+			if name = strings.TrimSpace(name); name != "" && !externalMetricNamePattern.MatchString(name) {
+				return fmt.Errorf("invalid kubernetes external metric name: %s", name)
+			}
+		}
+	}
+
+	// 实时指标缓冲验证
+	if c.Realtime.BufferSize <= 0 {
+		return fmt.Errorf("realtime buffer size must be positive")
+	}
+	if c.Realtime.StaleAfter <= 0 {
+		return fmt.Errorf("realtime stale after must be positive")
+	}
+
+	// 监控数据可见性验证
+	if err := c.Visibility.Validate(); err != nil {
+		return err
+	}
+
+	// 指标批量写入验证
+	if batch := c.StorageConfig.Batch; batch.Enabled {
+		if batch.Size <= 0 || batch.FlushInterval <= 0 {
+			return fmt.Errorf("metric batch size and flush interval must be positive")
+		}
+		if batch.MaxQueue < batch.Size {
+			return fmt.Errorf("metric batch max queue must be at least the batch size")
+		}
+		if batch.MaxRetries < 0 || batch.RetryBackoff < 0 {
+			return fmt.Errorf("metric batch retries and backoff must not be negative")
+		}
+	}
+
 	// 数据库监控验证
 	if c.DatabaseMonitoring.ConnectionThreshold <= 0 {
 		return fmt.Errorf("connection threshold must be positive")
@@ -445,11 +1198,20 @@ func (c *MonitoringConfig) Validate() error {
 	if c.CacheMonitoring.MemoryUsageThreshold < 0 || c.CacheMonitoring.MemoryUsageThreshold > 100 {
 		return fmt.Errorf("memory usage threshold must be between 0 and 100")
 	}
+	if c.CacheMonitoring.Enabled && c.CacheMonitoring.CheckInterval < time.Second {
+		return fmt.Errorf("cache check interval must be at least 1s")
+	}
+	if c.CacheMonitoring.KeySampleSize < 0 {
+		return fmt.Errorf("cache key sample size must not be negative")
+	}
 
 	// 告警配置验证
 	if c.AlertConfig.MaxEscalationLevel <= 0 {
 		return fmt.Errorf("max escalation level must be positive")
 	}
+	if c.AlertConfig.ResolveStableFor < 0 || c.AlertConfig.FlapWindow < 0 || c.AlertConfig.FlapThreshold < 0 {
+		return fmt.Errorf("alert resolve stable period and flap detection settings must not be negative")
+	}
 
 	// 通知配置验证
 	if c.NotificationConfig.Email.Enabled {
@@ -482,10 +1244,26 @@ func (c *MonitoringConfig) Validate() error {
 		}
 	}
 
-	if c.NotificationConfig.SMS.Enabled {
-		if c.NotificationConfig.SMS.APIKey == "" {
-			return fmt.Errorf("SMS API key is required when SMS notification is enabled")
+	if c.NotificationConfig.Telegram.Enabled {
+		if c.NotificationConfig.Telegram.BotToken == "" || c.NotificationConfig.Telegram.ChatIDs == "" {
+			return fmt.Errorf("Telegram bot token and chat IDs are required when Telegram notification is enabled")
+		}
+		if mode := c.NotificationConfig.Telegram.ParseMode; mode != "" && mode != "MarkdownV2" {
+			return fmt.Errorf("invalid Telegram parse mode: %s (must be MarkdownV2 or empty)", mode)
+		}
+	}
+
+	if c.NotificationConfig.WeChatWork.Enabled {
+		if c.NotificationConfig.WeChatWork.WebhookURL == "" {
+			return fmt.Errorf("WeChat Work webhook URL is required when WeChat Work notification is enabled")
 		}
+		if msgType := c.NotificationConfig.WeChatWork.MsgType; msgType != "" && msgType != "markdown" && msgType != "text" {
+			return fmt.Errorf("invalid WeChat Work message type: %s (must be markdown or text)", msgType)
+		}
+	}
+
+	// 短信服务商和密钥使用短信配置（SMS_*），这里只要求配置接收号码
+	if c.NotificationConfig.SMS.Enabled {
 		if c.NotificationConfig.SMS.PhoneNumbers == "" {
 			return fmt.Errorf("phone numbers are required when SMS notification is enabled")
 		}
@@ -0,0 +1,67 @@
+package Config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// NotificationConfig 站内通知配置
+//
+// 配置项说明：
+// - Enabled: 是否启用站内通知，关闭后系统事件不再生成通知
+// - PushEnabled: 是否通过SSE/WebSocket实时推送新通知和未读数
+// - RetentionDays: 通知保留天数，0表示不清理
+// - SecurityAlertLevels: 达到这些级别的安全事件通知管理员（标记为已告警的事件始终通知）
+// - SecurityAlertCooldown: 同类型同来源的安全告警通知间隔，避免攻击期间刷屏
+type NotificationConfig struct {
+	Enabled               bool          `mapstructure:"enabled"`
+	PushEnabled           bool          `mapstructure:"push_enabled"`
+	RetentionDays         int           `mapstructure:"retention_days"`
+	SecurityAlertLevels   []string      `mapstructure:"security_alert_levels"`
+	SecurityAlertCooldown time.Duration `mapstructure:"security_alert_cooldown"`
+}
+
+// SetDefaults 设置站内通知配置默认值
+func (n *NotificationConfig) SetDefaults() {
+	viper.SetDefault("notification.enabled", true)
+	viper.SetDefault("notification.push_enabled", true)
+	viper.SetDefault("notification.retention_days", 90)
+	viper.SetDefault("notification.security_alert_levels", []string{"high", "critical"})
+	viper.SetDefault("notification.security_alert_cooldown", "10m")
+}
+
+// BindEnvs 绑定站内通知环境变量
+func (n *NotificationConfig) BindEnvs() {
+	viper.BindEnv("notification.enabled", "NOTIFICATION_ENABLED")
+	viper.BindEnv("notification.push_enabled", "NOTIFICATION_PUSH_ENABLED")
+	viper.BindEnv("notification.retention_days", "NOTIFICATION_RETENTION_DAYS")
+	viper.BindEnv("notification.security_alert_levels", "NOTIFICATION_SECURITY_ALERT_LEVELS")
+	viper.BindEnv("notification.security_alert_cooldown", "NOTIFICATION_SECURITY_ALERT_COOLDOWN")
+}
+
+// Validate 验证站内通知配置
+func (n *NotificationConfig) Validate() error {
+	if n.RetentionDays < 0 {
+		return fmt.Errorf("通知保留天数不能为负数: %d", n.RetentionDays)
+	}
+	if n.SecurityAlertCooldown < 0 {
+		return fmt.Errorf("安全告警通知间隔不能为负数: %s", n.SecurityAlertCooldown)
+	}
+	return nil
+}
+
+// IsSecurityAlertLevel 安全事件级别是否需要通知管理员
+func (n *NotificationConfig) IsSecurityAlertLevel(level string) bool {
+	for _, candidate := range n.SecurityAlertLevels {
+		// 环境变量以逗号分隔的字符串传入时 viper 不会拆分
+		for _, item := range strings.Split(candidate, ",") {
+			if strings.EqualFold(strings.TrimSpace(item), level) {
+				return true
+			}
+		}
+	}
+	return false
+}
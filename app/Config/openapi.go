@@ -0,0 +1,60 @@
+package Config
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+// 请求校验模式
+const (
+	OpenAPIValidationOff     = "off"     // 不校验
+	OpenAPIValidationLenient = "lenient" // 校验已声明的参数和字段
+	OpenAPIValidationStrict  = "strict"  // 同时拒绝未声明的查询参数和请求体字段，用于测试
+)
+
+// OpenAPIConfig 接口文档配置
+// 功能说明：
+// 1. 接口文档由路由注册时声明的请求和响应类型生成，启用时在 /openapi.json 提供
+// 2. OutputFile 不为空时启动后把文档写入文件，供网关或客户端代码生成使用
+// 3. Validation 为 lenient 或 strict 时按文档校验请求，不符合时返回400；生产环境建议关闭
+type OpenAPIConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`     // 是否提供 /openapi.json
+	Validation string `mapstructure:"validation"`  // 请求校验模式：off、lenient、strict
+	OutputFile string `mapstructure:"output_file"` // 启动后写入文档的文件路径，为空不写入
+	ServerURL  string `mapstructure:"server_url"`  // 文档中的服务地址，为空时不输出
+}
+
+// SetDefaults 设置接口文档默认值
+func (o *OpenAPIConfig) SetDefaults() {
+	viper.SetDefault("openapi.enabled", true)
+	viper.SetDefault("openapi.validation", OpenAPIValidationOff)
+	viper.SetDefault("openapi.output_file", "")
+	viper.SetDefault("openapi.server_url", "")
+}
+
+// BindEnvs 绑定接口文档环境变量
+func (o *OpenAPIConfig) BindEnvs() {
+	viper.BindEnv("openapi.enabled", "OPENAPI_ENABLED")
+	viper.BindEnv("openapi.validation", "OPENAPI_VALIDATION")
+	viper.BindEnv("openapi.output_file", "OPENAPI_OUTPUT_FILE")
+	viper.BindEnv("openapi.server_url", "OPENAPI_SERVER_URL")
+}
+
+// Validate 验证接口文档配置
+func (o *OpenAPIConfig) Validate() error {
+	switch o.Validation {
+	case "", OpenAPIValidationOff, OpenAPIValidationLenient, OpenAPIValidationStrict:
+		return nil
+	default:
+		return fmt.Errorf("不支持的请求校验模式: %s，可选 off、lenient、strict", o.Validation)
+	}
+}
+
+// GetOpenAPIConfig 获取接口文档配置
+func GetOpenAPIConfig() *OpenAPIConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.OpenAPI
+}
@@ -24,6 +24,9 @@ type QueryOptimizationConfig struct {
 		LogFile               string        `mapstructure:"log_file" json:"log_file"`
 		AlertEnabled          bool          `mapstructure:"alert_enabled" json:"alert_enabled"`
 		AlertThreshold        time.Duration `mapstructure:"alert_threshold" json:"alert_threshold"`
+		TopN                  int           `mapstructure:"top_n" json:"top_n"`                   // 分析接口默认返回的慢语句数量
+		FlushInterval         time.Duration `mapstructure:"flush_interval" json:"flush_interval"` // 统计写入 slow_query_stats 表的间隔
+		SampleSize            int           `mapstructure:"sample_size" json:"sample_size"`       // 每条语句保留的最近耗时样本数（用于计算P95）
 	} `mapstructure:"slow_query" json:"slow_query"`
 
 	// 索引优化
@@ -104,6 +107,9 @@ func (c *QueryOptimizationConfig) SetDefaults() {
 	c.SlowQuery.LogFile = "logs/slow_queries.log"
 	c.SlowQuery.AlertEnabled = true
 	c.SlowQuery.AlertThreshold = 10 * time.Second
+	c.SlowQuery.TopN = 50
+	c.SlowQuery.FlushInterval = 1 * time.Minute
+	c.SlowQuery.SampleSize = 500
 
 	// 索引优化默认值
 	c.IndexOptimization.Enabled = true
@@ -159,6 +165,15 @@ func (c *QueryOptimizationConfig) BindEnvs() {
 	bindEnv("QUERY_OPTIMIZATION_ENABLED", &c.Enabled)
 	bindEnv("QUERY_OPTIMIZATION_INTERVAL", &c.Interval)
 	bindEnv("QUERY_OPTIMIZATION_RETENTION_PERIOD", &c.RetentionPeriod)
+
+	// 慢查询分析
+	bindEnv("QUERY_OPTIMIZATION_SLOW_QUERY_ENABLED", &c.SlowQuery.Enabled)
+	bindEnv("QUERY_OPTIMIZATION_SLOW_QUERY_THRESHOLD", &c.SlowQuery.Threshold)
+	bindEnv("QUERY_OPTIMIZATION_SLOW_QUERY_NOTIFICATION_THRESHOLD", &c.SlowQuery.NotificationThreshold)
+	bindEnv("QUERY_OPTIMIZATION_SLOW_QUERY_MAX_RECORDS", &c.SlowQuery.MaxRecords)
+	bindEnv("QUERY_OPTIMIZATION_SLOW_QUERY_TOP_N", &c.SlowQuery.TopN)
+	bindEnv("QUERY_OPTIMIZATION_SLOW_QUERY_FLUSH_INTERVAL", &c.SlowQuery.FlushInterval)
+	bindEnv("QUERY_OPTIMIZATION_SLOW_QUERY_SAMPLE_SIZE", &c.SlowQuery.SampleSize)
 }
 
 // LoadFromViper 从Viper加载配置
@@ -183,6 +198,14 @@ func (c *QueryOptimizationConfig) Validate() error {
 		c.SlowQuery.Threshold = 1 * time.Second
 	}
 
+	if c.SlowQuery.FlushInterval <= 0 {
+		c.SlowQuery.FlushInterval = 1 * time.Minute
+	}
+
+	if c.SlowQuery.SampleSize <= 0 {
+		c.SlowQuery.SampleSize = 500
+	}
+
 	if c.PerformanceMonitoring.Interval <= 0 {
 		c.PerformanceMonitoring.Interval = 1 * time.Minute
 	}
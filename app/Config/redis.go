@@ -3,6 +3,7 @@ package Config
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -14,14 +15,31 @@ type RedisConfig struct {
 	Password string `mapstructure:"password"`
 	Database int    `mapstructure:"database"`
 	DB       int    `mapstructure:"db"` // 添加 DB 字段，与 Database 字段兼容
+
+	// 部署模式：standalone（单机，默认）、cluster（集群）、sentinel（哨兵）
+	Mode string `mapstructure:"mode"`
+	// 集群节点或哨兵地址，逗号分隔；单机模式使用 Host 和 Port
+	Addrs string `mapstructure:"addrs"`
+	// 哨兵模式下的主节点名称
+	MasterName string `mapstructure:"master_name"`
+	// 哨兵节点密码，为空时不认证
+	SentinelPassword string `mapstructure:"sentinel_password"`
 }
 
+// Redis部署模式
+const (
+	RedisModeStandalone = "standalone"
+	RedisModeCluster    = "cluster"
+	RedisModeSentinel   = "sentinel"
+)
+
 // SetDefaults 设置Redis配置默认值
 func (r *RedisConfig) SetDefaults() {
 	viper.SetDefault("redis.host", "localhost")
 	viper.SetDefault("redis.port", "6379")
 	viper.SetDefault("redis.database", 0)
 	viper.SetDefault("redis.db", 0)
+	viper.SetDefault("redis.mode", RedisModeStandalone)
 }
 
 // BindEnvs 绑定Redis环境变量
@@ -31,6 +49,10 @@ func (r *RedisConfig) BindEnvs() {
 	viper.BindEnv("redis.password", "REDIS_PASSWORD")
 	viper.BindEnv("redis.database", "REDIS_DATABASE")
 	viper.BindEnv("redis.db", "REDIS_DB")
+	viper.BindEnv("redis.mode", "REDIS_MODE")
+	viper.BindEnv("redis.addrs", "REDIS_ADDRS")
+	viper.BindEnv("redis.master_name", "REDIS_MASTER_NAME")
+	viper.BindEnv("redis.sentinel_password", "REDIS_SENTINEL_PASSWORD")
 }
 
 // GetRedisConfig 获取Redis配置
@@ -46,6 +68,43 @@ func (r *RedisConfig) GetAddr() string {
 	return r.Host + ":" + strconv.Itoa(r.Port)
 }
 
+// GetMode 获取部署模式，未配置时为单机模式
+func (r *RedisConfig) GetMode() string {
+	mode := strings.ToLower(strings.TrimSpace(r.Mode))
+	if mode == "" {
+		return RedisModeStandalone
+	}
+	return mode
+}
+
+// GetAddrs 获取集群节点或哨兵地址列表
+// 未配置 Addrs 时返回 Host:Port
+func (r *RedisConfig) GetAddrs() []string {
+	var addrs []string
+	for _, addr := range strings.Split(r.Addrs, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	if len(addrs) == 0 && r.Host != "" {
+		addrs = append(addrs, r.GetAddr())
+	}
+	return addrs
+}
+
+// GetDB 获取数据库编号，优先使用 DB 字段
+func (r *RedisConfig) GetDB() int {
+	if r.DB == 0 && r.Database != 0 {
+		return r.Database
+	}
+	return r.DB
+}
+
+// IsConfigured 是否配置了Redis
+func (r *RedisConfig) IsConfigured() bool {
+	return r.Host != "" || strings.TrimSpace(r.Addrs) != ""
+}
+
 // GetConnectionString 获取Redis连接字符串
 func (r *RedisConfig) GetConnectionString() string {
 	// 优先使用 DB 字段，如果没有则使用 Database 字段
@@ -62,21 +121,34 @@ func (r *RedisConfig) GetConnectionString() string {
 
 // Validate 验证Redis配置
 func (r *RedisConfig) Validate() error {
-	if r.Host == "" {
-		return fmt.Errorf("Redis主机未配置")
-	}
+	switch r.GetMode() {
+	case RedisModeStandalone:
+		if r.Host == "" {
+			return fmt.Errorf("Redis主机未配置")
+		}
 
-	if r.Port <= 0 || r.Port > 65535 {
-		return fmt.Errorf("Redis端口配置无效: %d", r.Port)
+		if r.Port <= 0 || r.Port > 65535 {
+			return fmt.Errorf("Redis端口配置无效: %d", r.Port)
+		}
+	case RedisModeCluster:
+		if len(r.GetAddrs()) == 0 {
+			return fmt.Errorf("Redis集群模式需要配置节点地址")
+		}
+		// 集群模式只有0号数据库
+		return nil
+	case RedisModeSentinel:
+		if r.MasterName == "" {
+			return fmt.Errorf("Redis哨兵模式需要配置主节点名称")
+		}
+		if len(r.GetAddrs()) == 0 {
+			return fmt.Errorf("Redis哨兵模式需要配置哨兵地址")
+		}
+	default:
+		return fmt.Errorf("Redis部署模式无效: %s", r.Mode)
 	}
 
 	// 优先使用 DB 字段，如果没有则使用 Database 字段
-	db := r.DB
-	if db == 0 && r.Database != 0 {
-		db = r.Database
-	}
-
-	if db < 0 || db > 15 {
+	if db := r.GetDB(); db < 0 || db > 15 {
 		return fmt.Errorf("Redis数据库编号无效，应在0-15之间")
 	}
 
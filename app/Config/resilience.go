@@ -0,0 +1,119 @@
+package Config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ResilienceConfig 入站请求弹性保护配置
+// 功能说明：
+// 1. 舱壁隔离：限制每个路由的并发请求数，避免单个慢接口占满所有处理能力
+// 2. 依赖熔断：定期检查数据库、Redis等依赖，连续失败时熔断，依赖它的请求直接返回503
+type ResilienceConfig struct {
+	Enabled bool `mapstructure:"enabled" json:"enabled"`
+
+	// 舱壁隔离
+	Bulkhead struct {
+		Enabled      bool          `mapstructure:"enabled" json:"enabled"`
+		DefaultLimit int           `mapstructure:"default_limit" json:"default_limit"` // 每个路由的最大并发数，0表示不限制
+		MaxWait      time.Duration `mapstructure:"max_wait" json:"max_wait"`           // 等待空闲名额的最长时间，0表示立即拒绝
+		RouteLimits  string        `mapstructure:"route_limits" json:"route_limits"`   // 单独配置的路由并发数，格式 "METHOD /path=N,..."
+	} `mapstructure:"bulkhead" json:"bulkhead"`
+
+	// 依赖熔断
+	CircuitBreaker struct {
+		Enabled              bool          `mapstructure:"enabled" json:"enabled"`
+		CheckInterval        time.Duration `mapstructure:"check_interval" json:"check_interval"`               // 依赖健康检查间隔
+		CheckTimeout         time.Duration `mapstructure:"check_timeout" json:"check_timeout"`                 // 单次健康检查超时
+		FailureThreshold     int           `mapstructure:"failure_threshold" json:"failure_threshold"`         // 连续失败多少次后熔断
+		SuccessThreshold     int           `mapstructure:"success_threshold" json:"success_threshold"`         // 半开状态下连续成功多少次后恢复
+		OpenTimeout          time.Duration `mapstructure:"open_timeout" json:"open_timeout"`                   // 熔断后进入半开状态前的等待时间
+		RequiredDependencies []string      `mapstructure:"required_dependencies" json:"required_dependencies"` // 熔断时拒绝请求的依赖，其余依赖只记录状态
+		ExcludePaths         []string      `mapstructure:"exclude_paths" json:"exclude_paths"`                 // 不受熔断影响的路径前缀
+	} `mapstructure:"circuit_breaker" json:"circuit_breaker"`
+}
+
+// SetDefaults 设置默认值
+func (c *ResilienceConfig) SetDefaults() {
+	c.Enabled = true
+
+	c.Bulkhead.Enabled = true
+	c.Bulkhead.DefaultLimit = 100
+	c.Bulkhead.MaxWait = 100 * time.Millisecond
+	// 事件流和WebSocket等长连接在整个连接期间占用名额，默认不限制并发
+	c.Bulkhead.RouteLimits = "GET /api/v1/monitoring/stream=0,GET /api/v1/notifications/stream=0,GET /api/v1/logs/tail/:logger=0,GET /api/v1/ws/=0"
+
+	c.CircuitBreaker.Enabled = true
+	c.CircuitBreaker.CheckInterval = 5 * time.Second
+	c.CircuitBreaker.CheckTimeout = 2 * time.Second
+	c.CircuitBreaker.FailureThreshold = 3
+	c.CircuitBreaker.SuccessThreshold = 2
+	c.CircuitBreaker.OpenTimeout = 30 * time.Second
+	c.CircuitBreaker.RequiredDependencies = []string{"database"}
+	// 健康检查和监控接口需要在依赖故障时仍然可用
+	c.CircuitBreaker.ExcludePaths = []string{"/health", "/metrics", "/debug/pprof", "/api/v1/monitoring"}
+}
+
+// BindEnvs 绑定环境变量
+func (c *ResilienceConfig) BindEnvs() {
+	bindEnv("RESILIENCE_ENABLED", &c.Enabled)
+
+	bindEnv("RESILIENCE_BULKHEAD_ENABLED", &c.Bulkhead.Enabled)
+	bindEnv("RESILIENCE_BULKHEAD_DEFAULT_LIMIT", &c.Bulkhead.DefaultLimit)
+	bindEnv("RESILIENCE_BULKHEAD_MAX_WAIT", &c.Bulkhead.MaxWait)
+	bindEnv("RESILIENCE_BULKHEAD_ROUTE_LIMITS", &c.Bulkhead.RouteLimits)
+
+	bindEnv("RESILIENCE_BREAKER_ENABLED", &c.CircuitBreaker.Enabled)
+	bindEnv("RESILIENCE_BREAKER_CHECK_INTERVAL", &c.CircuitBreaker.CheckInterval)
+	bindEnv("RESILIENCE_BREAKER_CHECK_TIMEOUT", &c.CircuitBreaker.CheckTimeout)
+	bindEnv("RESILIENCE_BREAKER_FAILURE_THRESHOLD", &c.CircuitBreaker.FailureThreshold)
+	bindEnv("RESILIENCE_BREAKER_SUCCESS_THRESHOLD", &c.CircuitBreaker.SuccessThreshold)
+	bindEnv("RESILIENCE_BREAKER_OPEN_TIMEOUT", &c.CircuitBreaker.OpenTimeout)
+	bindEnv("RESILIENCE_BREAKER_REQUIRED_DEPENDENCIES", &c.CircuitBreaker.RequiredDependencies)
+	bindEnv("RESILIENCE_BREAKER_EXCLUDE_PATHS", &c.CircuitBreaker.ExcludePaths)
+}
+
+// Validate 验证配置
+func (c *ResilienceConfig) Validate() error {
+	if c.Bulkhead.DefaultLimit < 0 {
+		return fmt.Errorf("舱壁默认并发数不能为负数")
+	}
+	if _, err := c.ParseRouteLimits(); err != nil {
+		return err
+	}
+
+	if c.CircuitBreaker.Enabled {
+		if c.CircuitBreaker.CheckInterval < 100*time.Millisecond {
+			return fmt.Errorf("依赖健康检查间隔不能小于100ms")
+		}
+		if c.CircuitBreaker.FailureThreshold <= 0 || c.CircuitBreaker.SuccessThreshold <= 0 {
+			return fmt.Errorf("熔断失败次数和恢复成功次数必须大于0")
+		}
+		if c.CircuitBreaker.OpenTimeout <= 0 {
+			return fmt.Errorf("熔断等待时间必须大于0")
+		}
+	}
+	return nil
+}
+
+// ParseRouteLimits 解析单独配置的路由并发数
+//
+// 键为 "METHOD /path"（gin路由模板，如 "GET /api/v1/posts/:id"），省略方法时匹配所有方法。
+func (c *ResilienceConfig) ParseRouteLimits() (map[string]int, error) {
+	limits := make(map[string]int)
+	for _, item := range strings.Split(c.Bulkhead.RouteLimits, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		route, value, ok := strings.Cut(item, "=")
+		limit, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || err != nil || limit < 0 {
+			return nil, fmt.Errorf("路由并发数配置无效: %s", item)
+		}
+		limits[strings.Join(strings.Fields(route), " ")] = limit
+	}
+	return limits, nil
+}
@@ -0,0 +1,108 @@
+package Config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// SearchConfig 全文搜索配置
+//
+// 配置项说明：
+// - Driver: 搜索驱动，database（LIKE/tsvector，适合小规模部署）或 elasticsearch（兼容OpenSearch）
+// - DefaultPageSize/MaxPageSize: 默认和最大分页大小
+// - SyncInterval: 搜索引擎驱动下的增量同步间隔，0表示不自动同步
+// - SyncBatchSize: 重建索引和增量同步时每批读取的记录数
+// - Elasticsearch: Elasticsearch/OpenSearch 连接配置
+type SearchConfig struct {
+	Driver          string                    `mapstructure:"driver"`
+	DefaultPageSize int                       `mapstructure:"default_page_size"`
+	MaxPageSize     int                       `mapstructure:"max_page_size"`
+	SyncInterval    time.Duration             `mapstructure:"sync_interval"`
+	SyncBatchSize   int                       `mapstructure:"sync_batch_size"`
+	Elasticsearch   ElasticsearchSearchConfig `mapstructure:"elasticsearch"`
+}
+
+// ElasticsearchSearchConfig Elasticsearch/OpenSearch 连接配置
+type ElasticsearchSearchConfig struct {
+	Addresses   []string      `mapstructure:"addresses"`
+	Username    string        `mapstructure:"username"`
+	Password    string        `mapstructure:"password"`
+	APIKey      string        `mapstructure:"api_key"`
+	IndexPrefix string        `mapstructure:"index_prefix"`
+	Shards      int           `mapstructure:"shards"`
+	Replicas    int           `mapstructure:"replicas"`
+	Timeout     time.Duration `mapstructure:"timeout"`
+}
+
+// SetDefaults 设置搜索配置默认值
+func (s *SearchConfig) SetDefaults() {
+	viper.SetDefault("search.driver", "database")
+	viper.SetDefault("search.default_page_size", 10)
+	viper.SetDefault("search.max_page_size", 100)
+	viper.SetDefault("search.sync_interval", "1m")
+	viper.SetDefault("search.sync_batch_size", 500)
+	viper.SetDefault("search.elasticsearch.addresses", []string{"http://localhost:9200"})
+	viper.SetDefault("search.elasticsearch.index_prefix", "cloud-platform")
+	viper.SetDefault("search.elasticsearch.shards", 1)
+	viper.SetDefault("search.elasticsearch.replicas", 0)
+	viper.SetDefault("search.elasticsearch.timeout", "10s")
+}
+
+// BindEnvs 绑定搜索环境变量
+func (s *SearchConfig) BindEnvs() {
+	viper.BindEnv("search.driver", "SEARCH_DRIVER")
+	viper.BindEnv("search.default_page_size", "SEARCH_DEFAULT_PAGE_SIZE")
+	viper.BindEnv("search.max_page_size", "SEARCH_MAX_PAGE_SIZE")
+	viper.BindEnv("search.sync_interval", "SEARCH_SYNC_INTERVAL")
+	viper.BindEnv("search.sync_batch_size", "SEARCH_SYNC_BATCH_SIZE")
+	viper.BindEnv("search.elasticsearch.addresses", "SEARCH_ES_ADDRESSES")
+	viper.BindEnv("search.elasticsearch.username", "SEARCH_ES_USERNAME")
+	viper.BindEnv("search.elasticsearch.password", "SEARCH_ES_PASSWORD")
+	viper.BindEnv("search.elasticsearch.api_key", "SEARCH_ES_API_KEY")
+	viper.BindEnv("search.elasticsearch.index_prefix", "SEARCH_ES_INDEX_PREFIX")
+	viper.BindEnv("search.elasticsearch.shards", "SEARCH_ES_SHARDS")
+	viper.BindEnv("search.elasticsearch.replicas", "SEARCH_ES_REPLICAS")
+	viper.BindEnv("search.elasticsearch.timeout", "SEARCH_ES_TIMEOUT")
+}
+
+// Validate 验证搜索配置
+func (s *SearchConfig) Validate() error {
+	switch s.DriverName() {
+	case "database":
+	case "elasticsearch", "opensearch":
+		if len(s.Elasticsearch.Addresses) == 0 {
+			return fmt.Errorf("搜索引擎地址未配置")
+		}
+		if s.Elasticsearch.IndexPrefix == "" {
+			return fmt.Errorf("搜索索引前缀未配置")
+		}
+	default:
+		return fmt.Errorf("不支持的搜索驱动: %s", s.Driver)
+	}
+
+	if s.MaxPageSize > 0 && s.DefaultPageSize > s.MaxPageSize {
+		return fmt.Errorf("默认分页大小不能大于最大分页大小")
+	}
+
+	return nil
+}
+
+// DriverName 获取规范化后的驱动名称
+func (s *SearchConfig) DriverName() string {
+	driver := strings.ToLower(strings.TrimSpace(s.Driver))
+	if driver == "" {
+		return "database"
+	}
+	return driver
+}
+
+// GetSearchConfig 获取搜索配置
+func GetSearchConfig() *SearchConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.Search
+}
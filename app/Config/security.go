@@ -2,6 +2,7 @@ package Config
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -26,6 +27,30 @@ type SecurityConfig struct {
 	
 	// 威胁防护配置
 	ThreatProtection ThreatProtectionConfig `mapstructure:"threat_protection"`
+
+	// 威胁情报共享配置
+	ThreatIntelSharing ThreatIntelSharingConfig `mapstructure:"threat_intel_sharing"`
+
+	// 自动响应配置
+	Response SecurityResponseConfig `mapstructure:"response"`
+
+	// 账户解锁配置
+	AccountUnlock AccountUnlockConfig `mapstructure:"account_unlock"`
+
+	// 新设备登录提醒配置
+	LoginNotification LoginNotificationConfig `mapstructure:"login_notification"`
+
+	// 安全响应头配置
+	Headers SecurityHeadersConfig `mapstructure:"headers"`
+
+	// 安全态势评分配置
+	Posture SecurityPostureConfig `mapstructure:"posture"`
+
+	// 漏洞扫描配置
+	Scan SecurityScanConfig `mapstructure:"scan"`
+
+	// 安全数据归档配置
+	Archive SecurityArchiveConfig `mapstructure:"archive"`
 }
 
 // BaseSecurityConfig 基础安全配置
@@ -37,6 +62,7 @@ type BaseSecurityConfig struct {
 	PasswordHistoryCount       int           `mapstructure:"password_history_count"`      // 密码历史记录数量
 	ForcePasswordChange        bool          `mapstructure:"force_password_change"`       // 是否强制密码更改
 	PasswordChangeInterval     time.Duration `mapstructure:"password_change_interval"`   // 密码更改间隔
+	PasswordExpiryWarning      time.Duration `mapstructure:"password_expiry_warning"`    // 密码过期前提前发送提醒邮件的时间
 	AccountLockoutThreshold    int           `mapstructure:"account_lockout_threshold"`   // 账户锁定阈值
 	AccountLockoutDuration     time.Duration `mapstructure:"account_lockout_duration"`    // 账户锁定时间
 	InactiveAccountTimeout     time.Duration `mapstructure:"inactive_account_timeout"`    // 非活跃账户超时
@@ -92,6 +118,7 @@ type AnomalyDetectionConfig struct {
 	BehavioralAnalysis         bool          `mapstructure:"behavioral_analysis"`        // 行为分析
 	PatternRecognition         bool          `mapstructure:"pattern_recognition"`       // 模式识别
 	MachineLearningEnabled     bool          `mapstructure:"machine_learning_enabled"`   // 机器学习
+	MLModelType                string        `mapstructure:"ml_model_type"`              // ML模型类型
 	MLModelPath                string        `mapstructure:"ml_model_path"`              // ML模型路径
 	MLTrainingDataPath         string        `mapstructure:"ml_training_data_path"`      // ML训练数据路径
 	RealTimeAnalysis           bool          `mapstructure:"real_time_analysis"`         // 实时分析
@@ -143,6 +170,150 @@ type ThreatProtectionConfig struct {
 	CSPDirectives              string        `mapstructure:"csp_directives"`              // CSP指令
 }
 
+// ThreatIntelSharingConfig 威胁情报共享配置
+// 功能说明：
+// 1. 定期将新增的威胁情报指标推送到下游系统的Webhook
+// 2. 支持 json、csv、stix（STIX 2.1 Bundle）三种格式
+// 3. 配置了密钥时使用HMAC-SHA256签名请求体，下游可据此校验来源
+type ThreatIntelSharingConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`     // 是否启用Webhook推送
+	WebhookURL string        `mapstructure:"webhook_url"` // 推送地址
+	Format     string        `mapstructure:"format"`      // 推送格式
+	Secret     string        `mapstructure:"secret"`      // 签名密钥
+	Interval   time.Duration `mapstructure:"interval"`    // 推送间隔
+	BatchSize  int           `mapstructure:"batch_size"`  // 每次请求最多推送的指标数
+}
+
+// SecurityResponseConfig 安全事件自动响应配置
+// 功能说明：
+// 1. 按事件类型和风险评分匹配剧本，执行封禁IP、强制下线、要求MFA重新验证、禁用API密钥、通知等动作
+// 2. 剧本从 PlaybooksFile（JSON数组）加载；未配置剧本且开启 AutoBlockOnAnomaly 时使用内置的异常封禁剧本
+// 3. 演练模式下只记录将要执行的动作，不实际执行
+// 4. 破坏性动作默认进入待审批状态，超过 ApprovalTimeout 未审批则过期
+type SecurityResponseConfig struct {
+	Enabled         bool          `mapstructure:"enabled"`          // 是否启用自动响应
+	DryRun          bool          `mapstructure:"dry_run"`          // 演练模式
+	PlaybooksFile   string        `mapstructure:"playbooks_file"`   // 剧本文件路径
+	ApprovalTimeout time.Duration `mapstructure:"approval_timeout"` // 审批超时时间
+	Cooldown        time.Duration `mapstructure:"cooldown"`         // 同一剧本对同一用户/IP的最短触发间隔
+	BlockDuration   time.Duration `mapstructure:"block_duration"`   // 封禁IP动作未指定时长时的默认值
+}
+
+// AccountUnlockConfig 账户自助解锁配置
+// 功能说明：
+// 1. 账户因登录失败被锁定时，向用户邮箱发送带签名的解锁链接
+// 2. 链接使用 Secret 做HMAC-SHA256签名，未配置时使用JWT密钥；超过 LinkTTL 失效，解锁后不可重复使用
+// 3. 邮件服务未配置时不发送邮件，管理员仍可通过管理接口解锁
+type AccountUnlockConfig struct {
+	EmailEnabled bool          `mapstructure:"email_enabled"` // 是否发送解锁邮件
+	Secret       string        `mapstructure:"secret"`        // 解锁链接签名密钥
+	LinkTTL      time.Duration `mapstructure:"link_ttl"`      // 解锁链接有效期
+	BaseURL      string        `mapstructure:"base_url"`      // 解锁链接的服务地址
+}
+
+// LoginNotificationConfig 新设备登录提醒配置
+// 功能说明：
+// 1. 每次登录（成功和失败）都记录到登录尝试表，用户可以查询自己最近的登录记录
+// 2. 登录成功的设备（浏览器和操作系统）或国家在 KnownWindow 内没有成功登录过时，向用户邮箱发送提醒，首次登录不提醒
+// 3. 国家取自反向代理或CDN设置的 CountryHeader 请求头（如 Cloudflare 的 CF-IPCountry），没有该请求头时只按设备判断
+type LoginNotificationConfig struct {
+	EmailEnabled  bool          `mapstructure:"email_enabled"`  // 是否发送新设备登录提醒邮件
+	CountryHeader string        `mapstructure:"country_header"` // 客户端国家代码所在的请求头
+	KnownWindow   time.Duration `mapstructure:"known_window"`   // 在该时间内成功登录过的设备和国家视为已知
+}
+
+// SecurityHeadersConfig 安全响应头配置
+// 功能说明：
+// 1. 为所有响应设置 Content-Security-Policy、Strict-Transport-Security、X-Frame-Options、Referrer-Policy 和 Permissions-Policy
+// 2. CSP 使用威胁防护配置中的 ContentSecurityPolicy 和 CSPDirectives，配置了 CSPReportURI 时追加 report-uri 指令
+// 3. CSPReportOnly 开启时使用 Content-Security-Policy-Report-Only，只上报不拦截，便于上线新策略前观察
+// 4. HSTS 只在HTTPS请求（包括反向代理设置 X-Forwarded-Proto: https）上设置，HSTSMaxAge 为0时不设置
+// 5. 值为空的响应头不设置
+type SecurityHeadersConfig struct {
+	Enabled               bool          `mapstructure:"enabled"`                 // 是否设置安全响应头
+	CSPReportOnly         bool          `mapstructure:"csp_report_only"`         // CSP只上报不拦截
+	CSPReportURI          string        `mapstructure:"csp_report_uri"`          // CSP违规报告地址，为空时不追加 report-uri
+	CSPReportMaxBytes     int64         `mapstructure:"csp_report_max_bytes"`    // CSP违规报告请求体的最大字节数
+	HSTSMaxAge            time.Duration `mapstructure:"hsts_max_age"`            // HSTS有效期
+	HSTSIncludeSubdomains bool          `mapstructure:"hsts_include_subdomains"` // HSTS是否包含子域名
+	HSTSPreload           bool          `mapstructure:"hsts_preload"`            // HSTS是否加入预加载列表
+	FrameOptions          string        `mapstructure:"frame_options"`           // X-Frame-Options：DENY 或 SAMEORIGIN
+	ReferrerPolicy        string        `mapstructure:"referrer_policy"`         // Referrer-Policy
+	PermissionsPolicy     string        `mapstructure:"permissions_policy"`      // Permissions-Policy
+}
+
+// SecurityPostureConfig 安全态势评分配置
+// 功能说明：
+// 1. 按MFA普及率、过期未用的API密钥、扫描发现的严重漏洞、密码策略合规率、未处理的高风险事件五项计算0-100的安全评分
+// 2. 各项分数按权重加权平均，没有数据的项（如从未执行过安全扫描）不参与计算
+// 3. 每隔 SnapshotInterval 保存一次评分快照用于趋势图，超过 HistoryRetention 的快照被清理
+type SecurityPostureConfig struct {
+	Enabled          bool          `mapstructure:"enabled"`           // 是否启用安全态势评分
+	SnapshotInterval time.Duration `mapstructure:"snapshot_interval"` // 保存评分快照的间隔
+	HistoryRetention time.Duration `mapstructure:"history_retention"` // 评分快照保留时间
+	StaleAPIKeyAge   time.Duration `mapstructure:"stale_api_key_age"` // 超过该时间未使用的API密钥视为闲置
+	HighRiskWindow   time.Duration `mapstructure:"high_risk_window"`  // 统计未处理高风险事件的时间范围
+	MFAWeight        int           `mapstructure:"mfa_weight"`        // MFA普及率权重
+	APIKeyWeight     int           `mapstructure:"api_key_weight"`    // API密钥权重
+	VulnWeight       int           `mapstructure:"vuln_weight"`       // 漏洞权重
+	PasswordWeight   int           `mapstructure:"password_weight"`   // 密码策略合规权重
+	EventWeight      int           `mapstructure:"event_weight"`      // 高风险事件权重
+}
+
+// SecurityScanConfig 漏洞扫描配置
+// 功能说明：
+// 1. 按 Interval 定期执行所有扫描插件，结果保存到安全扫描结果表，管理员也可以手动触发
+// 2. 依赖漏洞：使用构建信息中的Go模块查询 OSV 漏洞库（OSVURL），需要访问外网
+// 3. TLS配置：连接 TLSTargets 中的 host:port，检查协议版本、证书信任和过期时间
+// 4. 端口扫描：对 PortScanHosts 中的主机探测 PortScanPorts，不在 AllowedPorts 中的开放端口视为风险
+// 5. JWT密钥强度和CORS策略检查不需要配置
+// 6. 依赖漏洞检查：按 DependencyCheckInterval 用内嵌的依赖清单查询OSV，新发现的漏洞记录为安全事件
+type SecurityScanConfig struct {
+	Enabled           bool          `mapstructure:"enabled"`             // 是否启用漏洞扫描（定期扫描和手动触发）
+	Interval          time.Duration `mapstructure:"interval"`            // 扫描间隔
+	Timeout           time.Duration `mapstructure:"timeout"`             // 单个扫描插件的超时时间
+	OSVEnabled        bool          `mapstructure:"osv_enabled"`         // 是否查询OSV依赖漏洞
+	OSVURL            string        `mapstructure:"osv_url"`             // OSV API地址
+	TLSTargets        []string      `mapstructure:"tls_targets"`         // TLS检查目标，host:port
+	CertExpiryWarning time.Duration `mapstructure:"cert_expiry_warning"` // 证书在该时间内过期时告警
+	PortScanHosts     []string      `mapstructure:"port_scan_hosts"`     // 端口扫描主机
+	PortScanPorts     []int         `mapstructure:"port_scan_ports"`     // 端口扫描的端口，为空时使用 DefaultScanPorts
+	AllowedPorts      []int         `mapstructure:"allowed_ports"`       // 允许对外开放的端口
+	DialTimeout       time.Duration `mapstructure:"dial_timeout"`        // 端口扫描和TLS检查的连接超时
+
+	DependencyCheckInterval time.Duration `mapstructure:"dependency_check_interval"` // 依赖漏洞检查间隔，0表示不定期检查
+}
+
+// SecurityArchiveConfig 安全数据归档配置
+// 功能说明：
+// 1. Tables 中的审计日志、安全事件和登录尝试超过 Retention 后导出为gzip压缩的JSON Lines文件，写入 ARCHIVE_STORE 配置的归档存储，读回校验后再从数据库删除
+// 2. 每个归档文件最多 RowsPerFile 条记录，数据库中的归档文件索引记录每个文件的表、时间范围、记录数和校验值
+// 3. 合规调查时按表、时间范围、字段和关键字搜索归档文件，单次搜索最多读取 MaxSearchFiles 个文件
+// 4. 归档由清理调度器的 security_archive 任务运行，已由大表分区归档（ARCHIVE_TABLES）管理的表跳过
+type SecurityArchiveConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`          // 是否在删除前归档安全数据，未启用时不删除
+	Tables         string        `mapstructure:"tables"`           // 归档的表，逗号分隔，支持 audit_logs、security_events、login_attempts
+	Retention      time.Duration `mapstructure:"retention"`        // 数据库中的保留时间，更早的记录归档后删除
+	RowsPerFile    int           `mapstructure:"rows_per_file"`    // 每个归档文件的最大记录数
+	Prefix         string        `mapstructure:"prefix"`           // 归档文件键前缀
+	MaxSearchFiles int           `mapstructure:"max_search_files"` // 单次搜索最多读取的归档文件数
+}
+
+// TableNames 返回规范化后的归档表名
+func (a *SecurityArchiveConfig) TableNames() []string {
+	names := make([]string, 0)
+	for _, name := range strings.Split(a.Tables, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// DefaultScanPorts 默认的端口扫描端口：远程管理、数据库、缓存和容器管理等常见服务
+// 不在 SetDefaults 中设置，避免环境变量中较短的列表与默认列表合并
+var DefaultScanPorts = []int{21, 22, 23, 25, 445, 2375, 2379, 3306, 3389, 5432, 6379, 9200, 11211, 27017}
+
 // SetDefaults 设置默认值
 func (c *SecurityConfig) SetDefaults() {
 	// 基础安全配置默认值
@@ -153,6 +324,7 @@ func (c *SecurityConfig) SetDefaults() {
 	c.BaseSecurity.PasswordHistoryCount = 5
 	c.BaseSecurity.ForcePasswordChange = false
 	c.BaseSecurity.PasswordChangeInterval = 90 * 24 * time.Hour // 90天
+	c.BaseSecurity.PasswordExpiryWarning = 7 * 24 * time.Hour   // 7天
 	c.BaseSecurity.AccountLockoutThreshold = 10
 	c.BaseSecurity.AccountLockoutDuration = 1 * time.Hour
 	c.BaseSecurity.InactiveAccountTimeout = 180 * 24 * time.Hour // 180天
@@ -202,6 +374,7 @@ func (c *SecurityConfig) SetDefaults() {
 	c.AnomalyDetection.BehavioralAnalysis = true
 	c.AnomalyDetection.PatternRecognition = true
 	c.AnomalyDetection.MachineLearningEnabled = false
+	c.AnomalyDetection.MLModelType = "statistical"
 	c.AnomalyDetection.MLModelPath = "models/anomaly_detection.model"
 	c.AnomalyDetection.MLTrainingDataPath = "data/training/"
 	c.AnomalyDetection.RealTimeAnalysis = true
@@ -247,6 +420,71 @@ func (c *SecurityConfig) SetDefaults() {
 	c.ThreatProtection.BlockedFileTypes = []string{".exe", ".bat", ".cmd", ".com", ".pif", ".scr", ".vbs", ".js"}
 	c.ThreatProtection.ContentSecurityPolicy = true
 	c.ThreatProtection.CSPDirectives = "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline';"
+
+	// 威胁情报共享配置
+	c.ThreatIntelSharing.Enabled = false
+	c.ThreatIntelSharing.Format = "stix"
+	c.ThreatIntelSharing.Interval = 5 * time.Minute
+	c.ThreatIntelSharing.BatchSize = 500
+
+	// 自动响应配置
+	c.Response.Enabled = false
+	c.Response.DryRun = false
+	c.Response.ApprovalTimeout = 24 * time.Hour
+	c.Response.Cooldown = 5 * time.Minute
+	c.Response.BlockDuration = 30 * time.Minute
+
+	// 账户解锁配置
+	c.AccountUnlock.EmailEnabled = true
+	c.AccountUnlock.LinkTTL = 24 * time.Hour
+	c.AccountUnlock.BaseURL = "http://localhost:8080"
+
+	// 新设备登录提醒配置
+	c.LoginNotification.EmailEnabled = true
+	c.LoginNotification.CountryHeader = "CF-IPCountry"
+	c.LoginNotification.KnownWindow = 90 * 24 * time.Hour
+
+	// 安全响应头配置
+	c.Headers.Enabled = true
+	c.Headers.CSPReportOnly = false
+	c.Headers.CSPReportURI = "/api/v1/csp-report"
+	c.Headers.CSPReportMaxBytes = 64 * 1024 // 64KB
+	c.Headers.HSTSMaxAge = 365 * 24 * time.Hour
+	c.Headers.HSTSIncludeSubdomains = true
+	c.Headers.HSTSPreload = false
+	c.Headers.FrameOptions = "DENY"
+	c.Headers.ReferrerPolicy = "strict-origin-when-cross-origin"
+	c.Headers.PermissionsPolicy = "geolocation=(), microphone=(), camera=()"
+
+	// 安全态势评分配置
+	c.Posture.Enabled = true
+	c.Posture.SnapshotInterval = 24 * time.Hour
+	c.Posture.HistoryRetention = 365 * 24 * time.Hour
+	c.Posture.StaleAPIKeyAge = 90 * 24 * time.Hour
+	c.Posture.HighRiskWindow = 7 * 24 * time.Hour
+	c.Posture.MFAWeight = 25
+	c.Posture.APIKeyWeight = 15
+	c.Posture.VulnWeight = 25
+	c.Posture.PasswordWeight = 15
+	c.Posture.EventWeight = 20
+
+	// 漏洞扫描配置
+	c.Scan.Enabled = true
+	c.Scan.Interval = 24 * time.Hour
+	c.Scan.Timeout = 2 * time.Minute
+	c.Scan.OSVEnabled = true
+	c.Scan.OSVURL = "https://api.osv.dev"
+	c.Scan.CertExpiryWarning = 30 * 24 * time.Hour
+	c.Scan.DialTimeout = 3 * time.Second
+	c.Scan.DependencyCheckInterval = 6 * time.Hour
+
+	// 安全数据归档配置
+	c.Archive.Enabled = false
+	c.Archive.Tables = "audit_logs,security_events,login_attempts"
+	c.Archive.Retention = 365 * 24 * time.Hour
+	c.Archive.RowsPerFile = 10000
+	c.Archive.Prefix = "security/"
+	c.Archive.MaxSearchFiles = 50
 }
 
 // BindEnvs 绑定环境变量
@@ -259,6 +497,7 @@ func (c *SecurityConfig) BindEnvs() {
 	viper.BindEnv("security.base_security.password_history_count", "SECURITY_PASSWORD_HISTORY_COUNT")
 	viper.BindEnv("security.base_security.force_password_change", "SECURITY_FORCE_PASSWORD_CHANGE")
 	viper.BindEnv("security.base_security.password_change_interval", "SECURITY_PASSWORD_CHANGE_INTERVAL")
+	viper.BindEnv("security.base_security.password_expiry_warning", "SECURITY_PASSWORD_EXPIRY_WARNING")
 	viper.BindEnv("security.base_security.account_lockout_threshold", "SECURITY_ACCOUNT_LOCKOUT_THRESHOLD")
 	viper.BindEnv("security.base_security.account_lockout_duration", "SECURITY_ACCOUNT_LOCKOUT_DURATION")
 	viper.BindEnv("security.base_security.inactive_account_timeout", "SECURITY_INACTIVE_ACCOUNT_TIMEOUT")
@@ -308,6 +547,7 @@ func (c *SecurityConfig) BindEnvs() {
 	viper.BindEnv("security.anomaly_detection.behavioral_analysis", "SECURITY_ANOMALY_BEHAVIORAL_ANALYSIS")
 	viper.BindEnv("security.anomaly_detection.pattern_recognition", "SECURITY_ANOMALY_PATTERN_RECOGNITION")
 	viper.BindEnv("security.anomaly_detection.machine_learning_enabled", "SECURITY_ANOMALY_ML_ENABLED")
+	viper.BindEnv("security.anomaly_detection.ml_model_type", "SECURITY_ANOMALY_ML_MODEL_TYPE")
 	viper.BindEnv("security.anomaly_detection.ml_model_path", "SECURITY_ANOMALY_ML_MODEL_PATH")
 	viper.BindEnv("security.anomaly_detection.ml_training_data_path", "SECURITY_ANOMALY_ML_TRAINING_DATA_PATH")
 	viper.BindEnv("security.anomaly_detection.real_time_analysis", "SECURITY_ANOMALY_REAL_TIME_ANALYSIS")
@@ -353,6 +593,79 @@ func (c *SecurityConfig) BindEnvs() {
 	viper.BindEnv("security.threat_protection.blocked_file_types", "SECURITY_THREAT_BLOCKED_FILE_TYPES")
 	viper.BindEnv("security.threat_protection.content_security_policy", "SECURITY_THREAT_CONTENT_SECURITY_POLICY")
 	viper.BindEnv("security.threat_protection.csp_directives", "SECURITY_THREAT_CSP_DIRECTIVES")
+
+	// 威胁情报共享配置
+	viper.BindEnv("security.threat_intel_sharing.enabled", "SECURITY_TI_SHARING_ENABLED")
+	viper.BindEnv("security.threat_intel_sharing.webhook_url", "SECURITY_TI_SHARING_WEBHOOK_URL")
+	viper.BindEnv("security.threat_intel_sharing.format", "SECURITY_TI_SHARING_FORMAT")
+	viper.BindEnv("security.threat_intel_sharing.secret", "SECURITY_TI_SHARING_SECRET")
+	viper.BindEnv("security.threat_intel_sharing.interval", "SECURITY_TI_SHARING_INTERVAL")
+	viper.BindEnv("security.threat_intel_sharing.batch_size", "SECURITY_TI_SHARING_BATCH_SIZE")
+
+	// 自动响应配置
+	viper.BindEnv("security.response.enabled", "SECURITY_RESPONSE_ENABLED")
+	viper.BindEnv("security.response.dry_run", "SECURITY_RESPONSE_DRY_RUN")
+	viper.BindEnv("security.response.playbooks_file", "SECURITY_RESPONSE_PLAYBOOKS_FILE")
+	viper.BindEnv("security.response.approval_timeout", "SECURITY_RESPONSE_APPROVAL_TIMEOUT")
+	viper.BindEnv("security.response.cooldown", "SECURITY_RESPONSE_COOLDOWN")
+	viper.BindEnv("security.response.block_duration", "SECURITY_RESPONSE_BLOCK_DURATION")
+
+	// 账户解锁配置
+	viper.BindEnv("security.account_unlock.email_enabled", "SECURITY_UNLOCK_EMAIL_ENABLED")
+	viper.BindEnv("security.account_unlock.secret", "SECURITY_UNLOCK_SECRET")
+	viper.BindEnv("security.account_unlock.link_ttl", "SECURITY_UNLOCK_LINK_TTL")
+	viper.BindEnv("security.account_unlock.base_url", "SECURITY_UNLOCK_BASE_URL")
+
+	// 新设备登录提醒配置
+	viper.BindEnv("security.login_notification.email_enabled", "SECURITY_LOGIN_NOTIFY_EMAIL_ENABLED")
+	viper.BindEnv("security.login_notification.country_header", "SECURITY_LOGIN_COUNTRY_HEADER")
+	viper.BindEnv("security.login_notification.known_window", "SECURITY_LOGIN_KNOWN_WINDOW")
+
+	// 安全响应头配置
+	viper.BindEnv("security.headers.enabled", "SECURITY_HEADERS_ENABLED")
+	viper.BindEnv("security.headers.csp_report_only", "SECURITY_HEADERS_CSP_REPORT_ONLY")
+	viper.BindEnv("security.headers.csp_report_uri", "SECURITY_HEADERS_CSP_REPORT_URI")
+	viper.BindEnv("security.headers.csp_report_max_bytes", "SECURITY_HEADERS_CSP_REPORT_MAX_BYTES")
+	viper.BindEnv("security.headers.hsts_max_age", "SECURITY_HEADERS_HSTS_MAX_AGE")
+	viper.BindEnv("security.headers.hsts_include_subdomains", "SECURITY_HEADERS_HSTS_INCLUDE_SUBDOMAINS")
+	viper.BindEnv("security.headers.hsts_preload", "SECURITY_HEADERS_HSTS_PRELOAD")
+	viper.BindEnv("security.headers.frame_options", "SECURITY_HEADERS_FRAME_OPTIONS")
+	viper.BindEnv("security.headers.referrer_policy", "SECURITY_HEADERS_REFERRER_POLICY")
+	viper.BindEnv("security.headers.permissions_policy", "SECURITY_HEADERS_PERMISSIONS_POLICY")
+
+	// 安全态势评分配置
+	viper.BindEnv("security.posture.enabled", "SECURITY_POSTURE_ENABLED")
+	viper.BindEnv("security.posture.snapshot_interval", "SECURITY_POSTURE_SNAPSHOT_INTERVAL")
+	viper.BindEnv("security.posture.history_retention", "SECURITY_POSTURE_HISTORY_RETENTION")
+	viper.BindEnv("security.posture.stale_api_key_age", "SECURITY_POSTURE_STALE_API_KEY_AGE")
+	viper.BindEnv("security.posture.high_risk_window", "SECURITY_POSTURE_HIGH_RISK_WINDOW")
+	viper.BindEnv("security.posture.mfa_weight", "SECURITY_POSTURE_MFA_WEIGHT")
+	viper.BindEnv("security.posture.api_key_weight", "SECURITY_POSTURE_API_KEY_WEIGHT")
+	viper.BindEnv("security.posture.vuln_weight", "SECURITY_POSTURE_VULN_WEIGHT")
+	viper.BindEnv("security.posture.password_weight", "SECURITY_POSTURE_PASSWORD_WEIGHT")
+	viper.BindEnv("security.posture.event_weight", "SECURITY_POSTURE_EVENT_WEIGHT")
+
+	// 漏洞扫描配置
+	viper.BindEnv("security.scan.enabled", "SECURITY_SCAN_ENABLED")
+	viper.BindEnv("security.scan.interval", "SECURITY_SCAN_INTERVAL")
+	viper.BindEnv("security.scan.timeout", "SECURITY_SCAN_TIMEOUT")
+	viper.BindEnv("security.scan.osv_enabled", "SECURITY_SCAN_OSV_ENABLED")
+	viper.BindEnv("security.scan.osv_url", "SECURITY_SCAN_OSV_URL")
+	viper.BindEnv("security.scan.tls_targets", "SECURITY_SCAN_TLS_TARGETS")
+	viper.BindEnv("security.scan.cert_expiry_warning", "SECURITY_SCAN_CERT_EXPIRY_WARNING")
+	viper.BindEnv("security.scan.port_scan_hosts", "SECURITY_SCAN_PORT_SCAN_HOSTS")
+	viper.BindEnv("security.scan.port_scan_ports", "SECURITY_SCAN_PORT_SCAN_PORTS")
+	viper.BindEnv("security.scan.allowed_ports", "SECURITY_SCAN_ALLOWED_PORTS")
+	viper.BindEnv("security.scan.dial_timeout", "SECURITY_SCAN_DIAL_TIMEOUT")
+	viper.BindEnv("security.scan.dependency_check_interval", "SECURITY_SCAN_DEPENDENCY_CHECK_INTERVAL")
+
+	// 安全数据归档配置
+	viper.BindEnv("security.archive.enabled", "SECURITY_ARCHIVE_ENABLED")
+	viper.BindEnv("security.archive.tables", "SECURITY_ARCHIVE_TABLES")
+	viper.BindEnv("security.archive.retention", "SECURITY_ARCHIVE_RETENTION")
+	viper.BindEnv("security.archive.rows_per_file", "SECURITY_ARCHIVE_ROWS_PER_FILE")
+	viper.BindEnv("security.archive.prefix", "SECURITY_ARCHIVE_PREFIX")
+	viper.BindEnv("security.archive.max_search_files", "SECURITY_ARCHIVE_MAX_SEARCH_FILES")
 }
 
 // Validate 验证配置
@@ -373,6 +686,14 @@ func (c *SecurityConfig) Validate() error {
 	if c.BaseSecurity.RateLimitRequests <= 0 {
 		return fmt.Errorf("rate_limit_requests must be greater than 0")
 	}
+	if c.BaseSecurity.ForcePasswordChange {
+		if c.BaseSecurity.PasswordChangeInterval <= 0 {
+			return fmt.Errorf("password_change_interval must be greater than 0 when force_password_change is enabled")
+		}
+		if c.BaseSecurity.PasswordExpiryWarning < 0 || c.BaseSecurity.PasswordExpiryWarning >= c.BaseSecurity.PasswordChangeInterval {
+			return fmt.Errorf("password_expiry_warning must be between 0 and password_change_interval")
+		}
+	}
 
 	// 密码策略配置验证
 	if c.PasswordPolicy.MinLength < 6 {
@@ -395,11 +716,133 @@ func (c *SecurityConfig) Validate() error {
 	if c.AnomalyDetection.AnomalyScoreThreshold < 0 || c.AnomalyDetection.AnomalyScoreThreshold > 1 {
 		return fmt.Errorf("anomaly_score_threshold must be between 0 and 1")
 	}
+	if c.AnomalyDetection.MachineLearningEnabled && c.AnomalyDetection.MLModelType == "" {
+		return fmt.Errorf("ml_model_type is required when machine learning is enabled")
+	}
 
 	// 威胁防护配置验证
 	if c.ThreatProtection.MaxFileSize <= 0 {
 		return fmt.Errorf("max_file_size must be greater than 0")
 	}
 
+	// 威胁情报共享配置验证
+	if c.ThreatIntelSharing.Enabled {
+		if c.ThreatIntelSharing.WebhookURL == "" {
+			return fmt.Errorf("threat_intel_sharing webhook_url is required when enabled")
+		}
+		switch c.ThreatIntelSharing.Format {
+		case "json", "csv", "stix":
+		default:
+			return fmt.Errorf("threat_intel_sharing format must be one of json, csv, stix")
+		}
+		if c.ThreatIntelSharing.Interval < time.Second {
+			return fmt.Errorf("threat_intel_sharing interval must be at least 1s")
+		}
+		if c.ThreatIntelSharing.BatchSize <= 0 {
+			return fmt.Errorf("threat_intel_sharing batch_size must be greater than 0")
+		}
+	}
+
+	// 自动响应配置验证
+	if c.Response.Enabled || c.AnomalyDetection.AutoBlockOnAnomaly {
+		if c.Response.ApprovalTimeout <= 0 {
+			return fmt.Errorf("response approval_timeout must be greater than 0")
+		}
+		if c.Response.Cooldown < 0 {
+			return fmt.Errorf("response cooldown must not be negative")
+		}
+		if c.Response.BlockDuration <= 0 {
+			return fmt.Errorf("response block_duration must be greater than 0")
+		}
+	}
+
+	// 账户解锁配置验证
+	if c.AccountUnlock.EmailEnabled {
+		if c.AccountUnlock.LinkTTL <= 0 {
+			return fmt.Errorf("account_unlock link_ttl must be greater than 0")
+		}
+		if c.AccountUnlock.BaseURL == "" {
+			return fmt.Errorf("account_unlock base_url is required when email is enabled")
+		}
+	}
+
+	// 新设备登录提醒配置验证
+	if c.LoginNotification.EmailEnabled && c.LoginNotification.KnownWindow <= 0 {
+		return fmt.Errorf("login_notification known_window must be greater than 0")
+	}
+
+	// 安全响应头配置验证
+	if c.Headers.Enabled {
+		switch strings.ToUpper(c.Headers.FrameOptions) {
+		case "", "DENY", "SAMEORIGIN":
+		default:
+			return fmt.Errorf("headers frame_options must be DENY or SAMEORIGIN")
+		}
+		if c.Headers.HSTSMaxAge < 0 {
+			return fmt.Errorf("headers hsts_max_age must not be negative")
+		}
+		if c.Headers.HSTSPreload && (c.Headers.HSTSMaxAge < 365*24*time.Hour || !c.Headers.HSTSIncludeSubdomains) {
+			return fmt.Errorf("headers hsts_preload requires hsts_max_age of at least 1 year and hsts_include_subdomains")
+		}
+	}
+	if c.Headers.CSPReportMaxBytes <= 0 {
+		return fmt.Errorf("headers csp_report_max_bytes must be greater than 0")
+	}
+
+	// 漏洞扫描配置验证
+	if c.Scan.Enabled && c.Scan.Interval < time.Minute {
+		return fmt.Errorf("scan interval must be at least 1m")
+	}
+	if c.Scan.Timeout <= 0 || c.Scan.DialTimeout <= 0 {
+		return fmt.Errorf("scan timeout and dial_timeout must be greater than 0")
+	}
+	if c.Scan.DependencyCheckInterval < 0 || (c.Scan.DependencyCheckInterval > 0 && c.Scan.DependencyCheckInterval < time.Minute) {
+		return fmt.Errorf("scan dependency_check_interval must be 0 or at least 1m")
+	}
+	if c.Scan.OSVEnabled && c.Scan.OSVURL == "" {
+		return fmt.Errorf("scan osv_url is required when osv is enabled")
+	}
+	for _, port := range append(append([]int{}, c.Scan.PortScanPorts...), c.Scan.AllowedPorts...) {
+		if port <= 0 || port > 65535 {
+			return fmt.Errorf("scan port %d is out of range", port)
+		}
+	}
+
+	// 安全态势评分配置验证
+	if c.Posture.Enabled {
+		if c.Posture.SnapshotInterval < time.Minute {
+			return fmt.Errorf("posture snapshot_interval must be at least 1m")
+		}
+		if c.Posture.HistoryRetention < c.Posture.SnapshotInterval {
+			return fmt.Errorf("posture history_retention must not be less than snapshot_interval")
+		}
+		if c.Posture.StaleAPIKeyAge <= 0 || c.Posture.HighRiskWindow <= 0 {
+			return fmt.Errorf("posture stale_api_key_age and high_risk_window must be greater than 0")
+		}
+		weights := []int{c.Posture.MFAWeight, c.Posture.APIKeyWeight, c.Posture.VulnWeight, c.Posture.PasswordWeight, c.Posture.EventWeight}
+		total := 0
+		for _, weight := range weights {
+			if weight < 0 {
+				return fmt.Errorf("posture weights must not be negative")
+			}
+			total += weight
+		}
+		if total == 0 {
+			return fmt.Errorf("posture weights must not all be 0")
+		}
+	}
+
+	if c.Archive.Enabled {
+		if len(c.Archive.TableNames()) == 0 {
+			return fmt.Errorf("archive tables must not be empty")
+		}
+		if c.Archive.Retention < 24*time.Hour {
+			return fmt.Errorf("archive retention must be at least 24h")
+		}
+		if c.Archive.RowsPerFile <= 0 || c.Archive.MaxSearchFiles <= 0 {
+			return fmt.Errorf("archive rows_per_file and max_search_files must be greater than 0")
+		}
+	}
+
 	return nil
 }
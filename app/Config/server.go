@@ -11,6 +11,8 @@ type ServerConfig struct {
 	Port    string `mapstructure:"port"`
 	Mode    string `mapstructure:"mode"`
 	BaseURL string `mapstructure:"base_url"`
+
+	Environment string `mapstructure:"environment"` // 部署环境名称，如 production、staging，用于区分配置快照
 }
 
 // SetDefaults 设置服务器配置默认值
@@ -18,6 +20,7 @@ func (s *ServerConfig) SetDefaults() {
 	viper.SetDefault("server.port", "8080")
 	viper.SetDefault("server.mode", "debug")
 	viper.SetDefault("server.base_url", "http://localhost:8080")
+	viper.SetDefault("server.environment", "development")
 }
 
 // BindEnvs 绑定服务器环境变量
@@ -26,6 +29,7 @@ func (s *ServerConfig) BindEnvs() {
 	viper.BindEnv("server.port", "SERVER_PORT") // 也支持 SERVER_PORT 环境变量
 	viper.BindEnv("server.mode", "SERVER_MODE")
 	viper.BindEnv("server.base_url", "SERVER_BASE_URL")
+	viper.BindEnv("server.environment", "APP_ENV")
 }
 
 // Validate 验证服务器配置
@@ -0,0 +1,67 @@
+package Config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// RequestSigningConfig 请求签名认证配置
+// 功能说明：
+// 1. 服务间调用可以使用 HMAC-SHA256 请求签名代替JWT，签名覆盖方法、路径、查询参数、指定的请求头和请求体
+// 2. 请求时间戳与服务器时间相差超过 MaxClockSkew 时拒绝，同一客户端的随机数在有效期内只能使用一次，防止重放
+// 3. 轮换密钥后旧密钥在 RotationGracePeriod 内仍然有效，调用方可以逐步切换
+type RequestSigningConfig struct {
+	Enabled              bool          `mapstructure:"enabled"`
+	MaxClockSkew         time.Duration `mapstructure:"max_clock_skew"`         // 请求时间戳允许的最大偏差
+	MaxBodyBytes         int64         `mapstructure:"max_body_bytes"`         // 参与签名的请求体最大字节数
+	RotationGracePeriod  time.Duration `mapstructure:"rotation_grace_period"`  // 未指定时轮换后旧密钥的有效期
+	NonceCleanupInterval time.Duration `mapstructure:"nonce_cleanup_interval"` // 清理过期随机数的间隔
+}
+
+// SetDefaults 设置请求签名默认值
+func (r *RequestSigningConfig) SetDefaults() {
+	viper.SetDefault("request_signing.enabled", true)
+	viper.SetDefault("request_signing.max_clock_skew", "5m")
+	viper.SetDefault("request_signing.max_body_bytes", 10*1024*1024)
+	viper.SetDefault("request_signing.rotation_grace_period", "24h")
+	viper.SetDefault("request_signing.nonce_cleanup_interval", "10m")
+}
+
+// BindEnvs 绑定请求签名环境变量
+func (r *RequestSigningConfig) BindEnvs() {
+	viper.BindEnv("request_signing.enabled", "REQUEST_SIGNING_ENABLED")
+	viper.BindEnv("request_signing.max_clock_skew", "REQUEST_SIGNING_MAX_CLOCK_SKEW")
+	viper.BindEnv("request_signing.max_body_bytes", "REQUEST_SIGNING_MAX_BODY_BYTES")
+	viper.BindEnv("request_signing.rotation_grace_period", "REQUEST_SIGNING_ROTATION_GRACE_PERIOD")
+	viper.BindEnv("request_signing.nonce_cleanup_interval", "REQUEST_SIGNING_NONCE_CLEANUP_INTERVAL")
+}
+
+// Validate 验证请求签名配置，未启用时不检查
+func (r *RequestSigningConfig) Validate() error {
+	if !r.Enabled {
+		return nil
+	}
+	if r.MaxClockSkew <= 0 {
+		return fmt.Errorf("请求签名时间戳最大偏差必须大于0")
+	}
+	if r.MaxBodyBytes <= 0 {
+		return fmt.Errorf("请求签名请求体最大字节数必须大于0")
+	}
+	if r.RotationGracePeriod < 0 {
+		return fmt.Errorf("请求签名密钥轮换宽限期不能为负数")
+	}
+	if r.NonceCleanupInterval <= 0 {
+		return fmt.Errorf("请求签名随机数清理间隔必须大于0")
+	}
+	return nil
+}
+
+// GetRequestSigningConfig 获取请求签名配置
+func GetRequestSigningConfig() *RequestSigningConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.RequestSigning
+}
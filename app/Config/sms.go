@@ -0,0 +1,137 @@
+package Config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 短信发送驱动
+const (
+	SMSDriverAliyun = "aliyun"
+	SMSDriverTwilio = "twilio"
+	SMSDriverLog    = "log" // 只记录日志不发送，用于开发和测试环境
+)
+
+// SMSConfig 短信配置
+// 功能说明：
+// 1. 支持阿里云短信和Twilio两种服务商，log 驱动只记录日志
+// 2. Senders 按国家区号配置发送方：阿里云为短信签名，Twilio为发送号码，未匹配的号码使用默认发送方
+// 3. 验证码的长度、有效期、发送间隔和最大尝试次数用于MFA短信验证
+type SMSConfig struct {
+	Driver         string `mapstructure:"driver"`          // 发送驱动：aliyun、twilio、log，为空表示不启用
+	DefaultCountry string `mapstructure:"default_country"` // 未带国家区号的号码使用的区号，如 86
+	Senders        string `mapstructure:"senders"`         // 按国家区号配置发送方，格式为 "区号:发送方,区号:发送方"
+
+	// 阿里云短信
+	AccessKeyID     string `mapstructure:"access_key_id"`
+	AccessKeySecret string `mapstructure:"access_key_secret"`
+	SignName        string `mapstructure:"sign_name"` // 默认短信签名
+	Region          string `mapstructure:"region"`
+
+	// Twilio
+	AccountSID          string `mapstructure:"account_sid"`
+	AuthToken           string `mapstructure:"auth_token"`
+	From                string `mapstructure:"from"`                  // 默认发送号码
+	MessagingServiceSID string `mapstructure:"messaging_service_sid"` // 使用消息服务发送时替代发送号码
+	StatusCallbackURL   string `mapstructure:"status_callback_url"`   // 送达回执回调地址（需包含 token 参数）
+
+	Endpoint      string        `mapstructure:"endpoint"`       // 覆盖服务商API地址（代理或本地模拟服务）
+	Timeout       time.Duration `mapstructure:"timeout"`        // 单次发送超时
+	MaxRetries    int           `mapstructure:"max_retries"`    // 临时失败最大重试次数
+	WebhookSecret string        `mapstructure:"webhook_secret"` // 送达回执回调接口校验令牌
+
+	// 短信验证码
+	CodeLength      int           `mapstructure:"code_length"`
+	CodeTTL         time.Duration `mapstructure:"code_ttl"`
+	CodeInterval    time.Duration `mapstructure:"code_interval"` // 同一号码两次发送的最小间隔
+	CodeMaxAttempts int           `mapstructure:"code_max_attempts"`
+}
+
+// SetDefaults 设置短信配置默认值
+func (s *SMSConfig) SetDefaults() {
+	viper.SetDefault("sms.driver", "")
+	viper.SetDefault("sms.default_country", "86")
+	viper.SetDefault("sms.region", "cn-hangzhou")
+	viper.SetDefault("sms.timeout", "10s")
+	viper.SetDefault("sms.max_retries", 2)
+	viper.SetDefault("sms.code_length", 6)
+	viper.SetDefault("sms.code_ttl", "5m")
+	viper.SetDefault("sms.code_interval", "1m")
+	viper.SetDefault("sms.code_max_attempts", 5)
+}
+
+// BindEnvs 绑定短信环境变量
+func (s *SMSConfig) BindEnvs() {
+	viper.BindEnv("sms.driver", "SMS_DRIVER")
+	viper.BindEnv("sms.default_country", "SMS_DEFAULT_COUNTRY")
+	viper.BindEnv("sms.senders", "SMS_SENDERS")
+	viper.BindEnv("sms.access_key_id", "SMS_ACCESS_KEY_ID")
+	viper.BindEnv("sms.access_key_secret", "SMS_ACCESS_KEY_SECRET")
+	viper.BindEnv("sms.sign_name", "SMS_SIGN_NAME")
+	viper.BindEnv("sms.region", "SMS_REGION")
+	viper.BindEnv("sms.account_sid", "SMS_ACCOUNT_SID")
+	viper.BindEnv("sms.auth_token", "SMS_AUTH_TOKEN")
+	viper.BindEnv("sms.from", "SMS_FROM")
+	viper.BindEnv("sms.messaging_service_sid", "SMS_MESSAGING_SERVICE_SID")
+	viper.BindEnv("sms.status_callback_url", "SMS_STATUS_CALLBACK_URL")
+	viper.BindEnv("sms.endpoint", "SMS_ENDPOINT")
+	viper.BindEnv("sms.timeout", "SMS_TIMEOUT")
+	viper.BindEnv("sms.max_retries", "SMS_MAX_RETRIES")
+	viper.BindEnv("sms.webhook_secret", "SMS_WEBHOOK_SECRET")
+	viper.BindEnv("sms.code_length", "SMS_CODE_LENGTH")
+	viper.BindEnv("sms.code_ttl", "SMS_CODE_TTL")
+	viper.BindEnv("sms.code_interval", "SMS_CODE_INTERVAL")
+	viper.BindEnv("sms.code_max_attempts", "SMS_CODE_MAX_ATTEMPTS")
+}
+
+// Validate 验证短信配置，未启用时不校验
+func (s *SMSConfig) Validate() error {
+	switch s.Driver {
+	case "", SMSDriverLog:
+	case SMSDriverAliyun:
+		if s.AccessKeyID == "" || s.AccessKeySecret == "" {
+			return fmt.Errorf("阿里云短信访问密钥不能为空")
+		}
+		if s.SignName == "" && s.Senders == "" {
+			return fmt.Errorf("阿里云短信签名不能为空")
+		}
+	case SMSDriverTwilio:
+		if s.AccountSID == "" || s.AuthToken == "" {
+			return fmt.Errorf("Twilio账户SID和认证令牌不能为空")
+		}
+		if s.From == "" && s.MessagingServiceSID == "" && s.Senders == "" {
+			return fmt.Errorf("Twilio发送号码和消息服务SID不能同时为空")
+		}
+	default:
+		return fmt.Errorf("不支持的短信驱动: %s", s.Driver)
+	}
+
+	if s.MaxRetries < 0 || s.CodeMaxAttempts < 0 {
+		return fmt.Errorf("短信重试次数和验证码尝试次数不能为负数")
+	}
+	if s.CodeLength != 0 && (s.CodeLength < 4 || s.CodeLength > 10) {
+		return fmt.Errorf("短信验证码长度必须在4到10之间: %d", s.CodeLength)
+	}
+	return nil
+}
+
+// IsConfigured 是否已配置短信驱动
+func (s *SMSConfig) IsConfigured() bool {
+	return s.Driver != ""
+}
+
+// SenderMap 解析按国家区号配置的发送方，格式为 "区号:发送方,区号:发送方"
+func (s *SMSConfig) SenderMap() map[string]string {
+	senders := make(map[string]string)
+	for _, pair := range strings.Split(s.Senders, ",") {
+		country, sender, ok := strings.Cut(pair, ":")
+		country = strings.TrimPrefix(strings.TrimSpace(country), "+")
+		if ok && country != "" {
+			senders[country] = strings.TrimSpace(sender)
+		}
+	}
+	return senders
+}
@@ -0,0 +1,174 @@
+package Config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RedactedValue 脱敏后的敏感配置值，未设置的敏感配置保持为空
+const RedactedValue = "[REDACTED]"
+
+// secretKeySuffixes 敏感配置键的后缀，键的最后一段去掉 _ 和 - 后比较
+// 如 jwt.secret、database.password、storage.encryption_key、storage.encryption_previous_keys
+var secretKeySuffixes = []string{"password", "passwd", "secret", "token", "key", "keys", "dsn", "credentials"}
+
+// Flatten 将配置展开为 section.key 形式的键值，键与配置文件和viper中的键相同
+// 时长输出为 1h30m0s 形式的字符串，切片输出为数组
+func (c *Config) Flatten() map[string]interface{} {
+	values := make(map[string]interface{})
+	flattenConfigValue("", reflect.ValueOf(c).Elem(), values)
+	return values
+}
+
+// RedactedValues 展开配置并脱敏：敏感键的非空值替换为 RedactedValue，URL中的密码替换为 RedactedValue
+func (c *Config) RedactedValues() map[string]interface{} {
+	return RedactConfigValues(c.Flatten())
+}
+
+// RedactConfigValues 对展开后的配置脱敏，返回新的map
+func RedactConfigValues(values map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		redacted[key] = redactConfigValue(key, value)
+	}
+	return redacted
+}
+
+// IsSecretConfigKey 检查配置键是否为敏感配置
+func IsSecretConfigKey(key string) bool {
+	name := key
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		name = key[i+1:]
+	}
+	name = strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(name))
+	for _, suffix := range secretKeySuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactConfigValue 脱敏单个配置值
+func redactConfigValue(key string, value interface{}) interface{} {
+	if IsSecretConfigKey(key) {
+		switch v := value.(type) {
+		case nil:
+			return nil
+		case string:
+			if v == "" {
+				return ""
+			}
+		case []interface{}:
+			if len(v) == 0 {
+				return v
+			}
+		}
+		return RedactedValue
+	}
+	switch v := value.(type) {
+	case string:
+		return redactURLPassword(v)
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			if s, ok := item.(string); ok {
+				items[i] = redactURLPassword(s)
+			} else {
+				items[i] = item
+			}
+		}
+		return items
+	}
+	return value
+}
+
+// redactURLPassword 替换URL中的密码，如 redis://:secret@host:6379 替换为 redis://:[REDACTED]@host:6379
+func redactURLPassword(value string) string {
+	i := strings.Index(value, "://")
+	if i < 0 {
+		return value
+	}
+	authority := value[i+3:]
+	if end := strings.IndexAny(authority, "/?#"); end >= 0 {
+		authority = authority[:end]
+	}
+	at := strings.LastIndex(authority, "@")
+	if at < 0 {
+		return value
+	}
+	colon := strings.Index(authority[:at], ":")
+	if colon < 0 {
+		return value
+	}
+	return value[:i+3+colon+1] + RedactedValue + value[i+3+at:]
+}
+
+// flattenConfigValue 递归展开配置结构体，键为 mapstructure 标签，没有标签时为小写的字段名
+func flattenConfigValue(prefix string, v reflect.Value, values map[string]interface{}) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			if prefix != "" {
+				name = prefix + "." + name
+			}
+			flattenConfigValue(name, v.Field(i), values)
+		}
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			values[prefix] = nil
+			return
+		}
+		flattenConfigValue(prefix, v.Elem(), values)
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, key := range keys {
+			flattenConfigValue(prefix+"."+fmt.Sprint(key.Interface()), v.MapIndex(key), values)
+		}
+	case reflect.Slice, reflect.Array:
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = configScalar(v.Index(i))
+		}
+		values[prefix] = items
+	default:
+		values[prefix] = configScalar(v)
+	}
+}
+
+// configScalar 转换为可以JSON序列化并稳定比较的值
+func configScalar(v reflect.Value) interface{} {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		return time.Duration(v.Int()).String()
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.String:
+		return v.String()
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
@@ -0,0 +1,79 @@
+package Config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// StartupConfig 启动依赖等待配置
+// 功能说明：
+// 1. 启动时等待数据库、Redis 等依赖就绪，Docker/K8s 中依赖通常晚于应用启动
+// 2. 关键依赖按指数退避重试，超过最长等待时间仍未就绪时启动失败
+// 3. 启用降级模式时关键依赖未就绪也继续启动，依赖相关的功能暂停使用，后台继续重试，恢复后自动启用
+// 4. 非关键依赖只检查一次，不可用时直接降级，后台继续重试
+type StartupConfig struct {
+	WaitEnabled          bool          `mapstructure:"wait_enabled"`          // 是否等待依赖就绪，关闭时只检查一次
+	Timeout              time.Duration `mapstructure:"timeout"`               // 等待关键依赖的最长时间
+	InitialBackoff       time.Duration `mapstructure:"initial_backoff"`       // 首次重试间隔
+	MaxBackoff           time.Duration `mapstructure:"max_backoff"`           // 最大重试间隔，也用于降级后的后台重试
+	CheckTimeout         time.Duration `mapstructure:"check_timeout"`         // 单次检查的超时
+	CriticalDependencies string        `mapstructure:"critical_dependencies"` // 关键依赖，逗号分隔，如 "database,redis"
+	DegradedMode         bool          `mapstructure:"degraded_mode"`         // 关键依赖未就绪时是否以降级模式启动
+}
+
+// SetDefaults 设置启动依赖等待默认值
+func (s *StartupConfig) SetDefaults() {
+	viper.SetDefault("startup.wait_enabled", true)
+	viper.SetDefault("startup.timeout", "2m")
+	viper.SetDefault("startup.initial_backoff", "1s")
+	viper.SetDefault("startup.max_backoff", "15s")
+	viper.SetDefault("startup.check_timeout", "5s")
+	viper.SetDefault("startup.critical_dependencies", "database")
+	viper.SetDefault("startup.degraded_mode", false)
+}
+
+// BindEnvs 绑定启动依赖等待环境变量
+func (s *StartupConfig) BindEnvs() {
+	viper.BindEnv("startup.wait_enabled", "STARTUP_WAIT_ENABLED")
+	viper.BindEnv("startup.timeout", "STARTUP_WAIT_TIMEOUT")
+	viper.BindEnv("startup.initial_backoff", "STARTUP_INITIAL_BACKOFF")
+	viper.BindEnv("startup.max_backoff", "STARTUP_MAX_BACKOFF")
+	viper.BindEnv("startup.check_timeout", "STARTUP_CHECK_TIMEOUT")
+	viper.BindEnv("startup.critical_dependencies", "STARTUP_CRITICAL_DEPENDENCIES")
+	viper.BindEnv("startup.degraded_mode", "STARTUP_DEGRADED_MODE")
+}
+
+// Validate 验证启动依赖等待配置
+func (s *StartupConfig) Validate() error {
+	if s.WaitEnabled && s.Timeout <= 0 {
+		return fmt.Errorf("依赖等待时间必须大于0")
+	}
+	if s.InitialBackoff <= 0 || s.MaxBackoff < s.InitialBackoff {
+		return fmt.Errorf("重试间隔必须大于0，且最大重试间隔不能小于首次重试间隔")
+	}
+	if s.CheckTimeout <= 0 {
+		return fmt.Errorf("依赖检查超时必须大于0")
+	}
+	return nil
+}
+
+// IsCritical 检查依赖是否为关键依赖
+func (s *StartupConfig) IsCritical(name string) bool {
+	for _, item := range strings.Split(s.CriticalDependencies, ",") {
+		if strings.EqualFold(strings.TrimSpace(item), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetStartupConfig 获取启动依赖等待配置
+func GetStartupConfig() *StartupConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.Startup
+}
@@ -1,9 +1,12 @@
 package Config
 
 import (
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -27,6 +30,22 @@ type StorageConfig struct {
 	EnableCompression   bool     `mapstructure:"enable_compression"`    // 启用压缩
 	EnableEncryption    bool     `mapstructure:"enable_encryption"`     // 启用加密
 	EncryptionKey       string   `mapstructure:"encryption_key"`        // 加密密钥
+
+	// 备份加密：每个备份使用独立的数据密钥，数据密钥由主密钥包装后写入备份文件头
+	EncryptionKeyProvider  string   `mapstructure:"encryption_key_provider"`  // 主密钥来源：local（EncryptionKey）或 vault（Vault Transit）
+	EncryptionKeyVersion   string   `mapstructure:"encryption_key_version"`   // 本地主密钥版本，写入备份元数据
+	EncryptionPreviousKeys []string `mapstructure:"encryption_previous_keys"` // 轮换前的本地主密钥，格式 版本:密钥，用于解密旧备份
+	EncryptionVaultAddress string   `mapstructure:"encryption_vault_address"` // Vault地址
+	EncryptionVaultToken   string   `mapstructure:"encryption_vault_token"`   // Vault令牌
+	EncryptionVaultKey     string   `mapstructure:"encryption_vault_key"`     // Vault Transit密钥名
+	BackupEscrowPublicKey  string   `mapstructure:"backup_escrow_public_key"` // 托管公钥（PEM文件），数据密钥另以该公钥加密，主密钥丢失时用私钥恢复
+
+	RestoreDrillEnabled  bool     `mapstructure:"restore_drill_enabled"`  // 启用定时恢复演练
+	RestoreDrillInterval int      `mapstructure:"restore_drill_interval"` // 恢复演练间隔（小时）
+	RestoreDrillTables   []string `mapstructure:"restore_drill_tables"`   // 恢复演练冒烟查询的表，恢复后必须存在且可查询
+
+	BackupSchedulesEnabled      bool          `mapstructure:"backup_schedules_enabled"`       // 运行定时备份计划
+	BackupScheduleCheckInterval time.Duration `mapstructure:"backup_schedule_check_interval"` // 检查到期备份计划的间隔
 }
 
 // SetDefaults 设置存储配置默认值
@@ -48,6 +67,18 @@ func (s *StorageConfig) SetDefaults() {
 	viper.SetDefault("storage.enable_compression", false)
 	viper.SetDefault("storage.enable_encryption", false)
 	viper.SetDefault("storage.encryption_key", "")
+	viper.SetDefault("storage.encryption_key_provider", "local")
+	viper.SetDefault("storage.encryption_key_version", "v1")
+	viper.SetDefault("storage.encryption_previous_keys", []string{})
+	viper.SetDefault("storage.encryption_vault_address", "")
+	viper.SetDefault("storage.encryption_vault_token", "")
+	viper.SetDefault("storage.encryption_vault_key", "backup")
+	viper.SetDefault("storage.backup_escrow_public_key", "")
+	viper.SetDefault("storage.restore_drill_enabled", false)
+	viper.SetDefault("storage.restore_drill_interval", 168)
+	viper.SetDefault("storage.restore_drill_tables", []string{"users", "migrations"})
+	viper.SetDefault("storage.backup_schedules_enabled", true)
+	viper.SetDefault("storage.backup_schedule_check_interval", "1m")
 }
 
 // BindEnvs 绑定存储环境变量
@@ -69,6 +100,18 @@ func (s *StorageConfig) BindEnvs() {
 	viper.BindEnv("storage.enable_compression", "STORAGE_ENABLE_COMPRESSION")
 	viper.BindEnv("storage.enable_encryption", "STORAGE_ENABLE_ENCRYPTION")
 	viper.BindEnv("storage.encryption_key", "STORAGE_ENCRYPTION_KEY")
+	viper.BindEnv("storage.encryption_key_provider", "STORAGE_ENCRYPTION_KEY_PROVIDER")
+	viper.BindEnv("storage.encryption_key_version", "STORAGE_ENCRYPTION_KEY_VERSION")
+	viper.BindEnv("storage.encryption_previous_keys", "STORAGE_ENCRYPTION_PREVIOUS_KEYS")
+	viper.BindEnv("storage.encryption_vault_address", "STORAGE_ENCRYPTION_VAULT_ADDRESS")
+	viper.BindEnv("storage.encryption_vault_token", "STORAGE_ENCRYPTION_VAULT_TOKEN")
+	viper.BindEnv("storage.encryption_vault_key", "STORAGE_ENCRYPTION_VAULT_KEY")
+	viper.BindEnv("storage.backup_escrow_public_key", "STORAGE_BACKUP_ESCROW_PUBLIC_KEY")
+	viper.BindEnv("storage.restore_drill_enabled", "STORAGE_RESTORE_DRILL_ENABLED")
+	viper.BindEnv("storage.restore_drill_interval", "STORAGE_RESTORE_DRILL_INTERVAL")
+	viper.BindEnv("storage.restore_drill_tables", "STORAGE_RESTORE_DRILL_TABLES")
+	viper.BindEnv("storage.backup_schedules_enabled", "STORAGE_BACKUP_SCHEDULES_ENABLED")
+	viper.BindEnv("storage.backup_schedule_check_interval", "STORAGE_BACKUP_SCHEDULE_CHECK_INTERVAL")
 }
 
 // GetStorageConfig 获取存储配置
@@ -138,9 +181,59 @@ func (s *StorageConfig) Validate() error {
 		return fmt.Errorf("允许的文件类型未配置")
 	}
 
+	if s.RestoreDrillEnabled && s.RestoreDrillInterval <= 0 {
+		return fmt.Errorf("恢复演练间隔必须大于0")
+	}
+
+	if s.BackupSchedulesEnabled && s.BackupScheduleCheckInterval <= 0 {
+		return fmt.Errorf("定时备份检查间隔必须大于0")
+	}
+
+	if s.EnableEncryption {
+		switch s.EncryptionKeyProvider {
+		case "", "local":
+			if _, err := DecodeBackupKey(s.EncryptionKey); err != nil {
+				return fmt.Errorf("备份加密密钥无效: %v", err)
+			}
+			if s.EncryptionKeyVersion == "" {
+				return fmt.Errorf("备份加密密钥版本未配置")
+			}
+			for _, entry := range s.EncryptionPreviousKeys {
+				version, key, ok := strings.Cut(entry, ":")
+				if !ok || version == "" {
+					return fmt.Errorf("轮换前的备份加密密钥格式应为 版本:密钥")
+				}
+				if _, err := DecodeBackupKey(key); err != nil {
+					return fmt.Errorf("备份加密密钥 %s 无效: %v", version, err)
+				}
+			}
+		case "vault":
+			if s.EncryptionVaultAddress == "" || s.EncryptionVaultToken == "" || s.EncryptionVaultKey == "" {
+				return fmt.Errorf("使用Vault加密备份时需要配置地址、令牌和密钥名")
+			}
+		default:
+			return fmt.Errorf("不支持的备份密钥来源: %s", s.EncryptionKeyProvider)
+		}
+	}
+
 	return nil
 }
 
+// DecodeBackupKey 解析备份主密钥，支持base64或十六进制编码的32字节密钥
+func DecodeBackupKey(key string) ([]byte, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil, fmt.Errorf("密钥为空")
+	}
+	if decoded, err := hex.DecodeString(key); err == nil && len(decoded) == 32 {
+		return decoded, nil
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(key); err == nil && len(decoded) == 32 {
+		return decoded, nil
+	}
+	return nil, fmt.Errorf("密钥应为base64或十六进制编码的32字节（可用 openssl rand -base64 32 生成）")
+}
+
 // GetAllowedTypesString 获取允许的文件类型字符串
 func (s *StorageConfig) GetAllowedTypesString() string {
 	return strings.Join(s.AllowedTypes, ", ")
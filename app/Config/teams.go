@@ -0,0 +1,52 @@
+package Config
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// TeamsConfig 组织和团队配置
+// 功能说明：
+// 1. 用户可以创建组织，组织所有者在组织下创建团队，团队管理员通过邮件邀请成员
+// 2. 邀请链接在 InvitationTTL 内有效，只能由邀请邮箱对应的用户接受一次
+// 3. 仪表板、告警规则和API密钥可以归属团队，团队成员共同查看和管理
+type TeamsConfig struct {
+	InvitationTTL     time.Duration `mapstructure:"invitation_ttl"`      // 邀请有效期
+	InvitationBaseURL string        `mapstructure:"invitation_base_url"` // 邀请邮件中接受链接的前端地址
+}
+
+// SetDefaults 设置组织和团队默认值
+func (t *TeamsConfig) SetDefaults() {
+	viper.SetDefault("teams.invitation_ttl", "168h")
+	viper.SetDefault("teams.invitation_base_url", "http://localhost:3000/invitations/accept")
+}
+
+// BindEnvs 绑定组织和团队环境变量
+func (t *TeamsConfig) BindEnvs() {
+	viper.BindEnv("teams.invitation_ttl", "TEAMS_INVITATION_TTL")
+	viper.BindEnv("teams.invitation_base_url", "TEAMS_INVITATION_BASE_URL")
+}
+
+// Validate 验证组织和团队配置
+func (t *TeamsConfig) Validate() error {
+	if t.InvitationTTL <= 0 {
+		return fmt.Errorf("团队邀请有效期必须大于0")
+	}
+	if t.InvitationBaseURL != "" {
+		if _, err := url.ParseRequestURI(t.InvitationBaseURL); err != nil {
+			return fmt.Errorf("无效的团队邀请链接地址: %s", t.InvitationBaseURL)
+		}
+	}
+	return nil
+}
+
+// GetTeamsConfig 获取组织和团队配置
+func GetTeamsConfig() *TeamsConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.Teams
+}
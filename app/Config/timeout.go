@@ -0,0 +1,85 @@
+package Config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// DefaultRequestTimeout 未单独配置的路由的请求超时
+const DefaultRequestTimeout = 30 * time.Second
+
+// RequestTimeoutConfig 请求超时配置
+// 功能说明：
+// 1. 每个请求的上下文带有截止时间，超时后数据库查询和出站HTTP请求随上下文取消
+// 2. 路由分组按路径前缀单独配置超时，如导出、报表等耗时接口放宽，登录等接口收紧
+// 3. 超时为0的分组不设置截止时间，用于SSE、WebSocket等长连接
+type RequestTimeoutConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	Default time.Duration `mapstructure:"default"` // 未匹配分组时的超时
+	Groups  string        `mapstructure:"groups"`  // 路由分组超时，格式 "/api/v1/exports=5m,/api/v1/auth=10s"
+}
+
+// RequestTimeoutGroup 路由分组超时
+type RequestTimeoutGroup struct {
+	Prefix  string
+	Timeout time.Duration
+}
+
+// SetDefaults 设置请求超时默认值
+func (r *RequestTimeoutConfig) SetDefaults() {
+	viper.SetDefault("request_timeout.enabled", true)
+	viper.SetDefault("request_timeout.default", DefaultRequestTimeout.String())
+	// 事件流、日志实时查看和WebSocket是长连接，不设置超时
+	viper.SetDefault("request_timeout.groups", "/api/v1/monitoring/stream=0,/api/v1/notifications/stream=0,/api/v1/logs/tail=0,/api/v1/ws=0,/ws=0")
+}
+
+// BindEnvs 绑定请求超时环境变量
+func (r *RequestTimeoutConfig) BindEnvs() {
+	viper.BindEnv("request_timeout.enabled", "REQUEST_TIMEOUT_ENABLED")
+	viper.BindEnv("request_timeout.default", "REQUEST_TIMEOUT_DEFAULT")
+	viper.BindEnv("request_timeout.groups", "REQUEST_TIMEOUT_GROUPS")
+}
+
+// Validate 验证请求超时配置，未启用时不检查
+func (r *RequestTimeoutConfig) Validate() error {
+	if !r.Enabled {
+		return nil
+	}
+	if r.Default < 0 {
+		return fmt.Errorf("默认请求超时不能为负数")
+	}
+	_, err := r.ParseGroups()
+	return err
+}
+
+// ParseGroups 解析路由分组超时，按前缀长度从长到短排序，匹配时取最长的前缀
+func (r *RequestTimeoutConfig) ParseGroups() ([]RequestTimeoutGroup, error) {
+	var groups []RequestTimeoutGroup
+	for _, item := range strings.Split(r.Groups, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		prefix, value, ok := strings.Cut(item, "=")
+		prefix = strings.TrimRight(strings.TrimSpace(prefix), "/")
+		timeout, err := time.ParseDuration(strings.TrimSpace(value))
+		if !ok || err != nil || timeout < 0 || !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("路由分组超时配置无效: %s", item)
+		}
+		groups = append(groups, RequestTimeoutGroup{Prefix: prefix, Timeout: timeout})
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return len(groups[i].Prefix) > len(groups[j].Prefix) })
+	return groups, nil
+}
+
+// GetRequestTimeoutConfig 获取请求超时配置
+func GetRequestTimeoutConfig() *RequestTimeoutConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.RequestTimeout
+}
@@ -0,0 +1,150 @@
+package Config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ServerTLSConfig API服务器TLS配置
+// 功能说明：
+// 1. 启用后API端口提供HTTPS，证书文件变化（包括Kubernetes Secret的符号链接切换）时自动重新加载，加载失败时继续使用旧证书
+// 2. 支持配置最低协议版本和TLS 1.2的加密套件，HTTP2 控制是否通过ALPN协商h2
+// 3. Autocert 启用后通过ACME（默认Let's Encrypt）按HTTP-01验证自动申请和续期证书，证书缓存在 AutocertCacheDir
+// 4. RedirectHTTP 启用后在 HTTPPort 监听HTTP请求并重定向到HTTPS；启用 Autocert 时该端口同时处理HTTP-01验证请求，总是监听
+type ServerTLSConfig struct {
+	Enabled      bool   `mapstructure:"enabled"`
+	CertFile     string `mapstructure:"cert_file"`     // 证书文件（PEM，可包含中间证书）
+	KeyFile      string `mapstructure:"key_file"`      // 私钥文件（PEM）
+	MinVersion   string `mapstructure:"min_version"`   // 最低协议版本：1.2、1.3
+	CipherSuites string `mapstructure:"cipher_suites"` // TLS 1.2的加密套件，逗号分隔的Go名称，为空时使用Go的默认安全套件
+	HTTP2        bool   `mapstructure:"http2"`         // 是否通过ALPN协商HTTP/2
+	RedirectHTTP bool   `mapstructure:"redirect_http"` // 是否在 HTTPPort 把HTTP请求重定向到HTTPS
+	HTTPPort     string `mapstructure:"http_port"`     // HTTP重定向和HTTP-01验证的监听端口
+
+	Autocert             bool   `mapstructure:"autocert"`               // 是否通过ACME自动申请证书
+	AutocertDomains      string `mapstructure:"autocert_domains"`       // 申请证书的域名，逗号分隔，只为这些域名申请
+	AutocertEmail        string `mapstructure:"autocert_email"`         // ACME账户联系邮箱，用于接收到期提醒
+	AutocertCacheDir     string `mapstructure:"autocert_cache_dir"`     // 证书和账户密钥的缓存目录
+	AutocertDirectoryURL string `mapstructure:"autocert_directory_url"` // ACME目录地址，为空时使用Let's Encrypt生产环境
+}
+
+// SetDefaults 设置TLS默认值
+func (t *ServerTLSConfig) SetDefaults() {
+	viper.SetDefault("tls.enabled", false)
+	viper.SetDefault("tls.cert_file", "")
+	viper.SetDefault("tls.key_file", "")
+	viper.SetDefault("tls.min_version", "1.2")
+	viper.SetDefault("tls.cipher_suites", "")
+	viper.SetDefault("tls.http2", true)
+	viper.SetDefault("tls.redirect_http", false)
+	viper.SetDefault("tls.http_port", "80")
+	viper.SetDefault("tls.autocert", false)
+	viper.SetDefault("tls.autocert_domains", "")
+	viper.SetDefault("tls.autocert_email", "")
+	viper.SetDefault("tls.autocert_cache_dir", "./storage/autocert")
+	viper.SetDefault("tls.autocert_directory_url", "")
+}
+
+// BindEnvs 绑定TLS环境变量
+func (t *ServerTLSConfig) BindEnvs() {
+	viper.BindEnv("tls.enabled", "TLS_ENABLED")
+	viper.BindEnv("tls.cert_file", "TLS_CERT_FILE")
+	viper.BindEnv("tls.key_file", "TLS_KEY_FILE")
+	viper.BindEnv("tls.min_version", "TLS_MIN_VERSION")
+	viper.BindEnv("tls.cipher_suites", "TLS_CIPHER_SUITES")
+	viper.BindEnv("tls.http2", "TLS_HTTP2")
+	viper.BindEnv("tls.redirect_http", "TLS_REDIRECT_HTTP")
+	viper.BindEnv("tls.http_port", "TLS_HTTP_PORT")
+	viper.BindEnv("tls.autocert", "TLS_AUTOCERT")
+	viper.BindEnv("tls.autocert_domains", "TLS_AUTOCERT_DOMAINS")
+	viper.BindEnv("tls.autocert_email", "TLS_AUTOCERT_EMAIL")
+	viper.BindEnv("tls.autocert_cache_dir", "TLS_AUTOCERT_CACHE_DIR")
+	viper.BindEnv("tls.autocert_directory_url", "TLS_AUTOCERT_DIRECTORY_URL")
+}
+
+// Validate 验证TLS配置，未启用时不检查
+func (t *ServerTLSConfig) Validate() error {
+	if !t.Enabled {
+		return nil
+	}
+	version, err := t.Version()
+	if err != nil {
+		return err
+	}
+	suites, err := t.CipherSuiteIDs()
+	if err != nil {
+		return err
+	}
+	if len(suites) > 0 && version == tls.VersionTLS13 {
+		return fmt.Errorf("TLS 1.3 的加密套件不可配置，最低版本为1.3时不要设置加密套件")
+	}
+	if t.Autocert {
+		if len(t.DomainList()) == 0 {
+			return fmt.Errorf("自动申请证书需要配置域名")
+		}
+		if t.AutocertCacheDir == "" {
+			return fmt.Errorf("自动申请证书需要配置缓存目录")
+		}
+	} else if t.CertFile == "" || t.KeyFile == "" {
+		return fmt.Errorf("启用TLS需要配置证书和私钥文件，或启用自动申请证书")
+	}
+	if (t.RedirectHTTP || t.Autocert) && t.HTTPPort == "" {
+		return fmt.Errorf("HTTP重定向和HTTP-01验证需要配置HTTP端口")
+	}
+	return nil
+}
+
+// Version 解析最低协议版本
+func (t *ServerTLSConfig) Version() (uint16, error) {
+	switch strings.TrimSpace(t.MinVersion) {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("不支持的TLS最低版本: %s，可选 1.2、1.3", t.MinVersion)
+	}
+}
+
+// CipherSuiteIDs 解析加密套件，只接受Go认为安全的套件
+func (t *ServerTLSConfig) CipherSuiteIDs() ([]uint16, error) {
+	secure := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		secure[suite.Name] = suite.ID
+	}
+	var ids []uint16
+	for _, name := range strings.Split(t.CipherSuites, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		id, ok := secure[name]
+		if !ok {
+			return nil, fmt.Errorf("不支持或不安全的加密套件: %s", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// DomainList 解析自动申请证书的域名
+func (t *ServerTLSConfig) DomainList() []string {
+	var domains []string
+	for _, domain := range strings.Split(t.AutocertDomains, ",") {
+		if domain = strings.ToLower(strings.TrimSpace(domain)); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
+
+// GetServerTLSConfig 获取API服务器TLS配置
+func GetServerTLSConfig() *ServerTLSConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.TLS
+}
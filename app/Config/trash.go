@@ -0,0 +1,63 @@
+package Config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// TrashConfig 回收站配置
+// 功能说明：
+// 1. 用户、告警规则、监控仪表板和API密钥删除后进入回收站，管理员可以恢复或彻底删除
+// 2. 删除时间超过 Retention 的记录每隔 PurgeInterval 自动彻底删除
+// 3. Retention 为0时不自动清理
+// 4. 彻底删除时关联记录超过 PurgeBatch 条的分批删除，每批一个事务
+// 5. 每隔 IntegrityInterval 统计引用已不存在记录的孤儿数据，作为监控指标输出，为0时不检查
+type TrashConfig struct {
+	Retention         time.Duration `mapstructure:"retention"`          // 回收站保留时间
+	PurgeInterval     time.Duration `mapstructure:"purge_interval"`     // 自动清理检查间隔
+	PurgeBatch        int           `mapstructure:"purge_batch"`        // 每次每种模型最多清理的记录数，也是关联记录每批删除的数量
+	IntegrityInterval time.Duration `mapstructure:"integrity_interval"` // 孤儿数据检查间隔
+}
+
+// SetDefaults 设置回收站默认值
+func (t *TrashConfig) SetDefaults() {
+	viper.SetDefault("trash.retention", "720h") // 30天
+	viper.SetDefault("trash.purge_interval", "1h")
+	viper.SetDefault("trash.purge_batch", 500)
+	viper.SetDefault("trash.integrity_interval", "1h")
+}
+
+// BindEnvs 绑定回收站环境变量
+func (t *TrashConfig) BindEnvs() {
+	viper.BindEnv("trash.retention", "TRASH_RETENTION")
+	viper.BindEnv("trash.purge_interval", "TRASH_PURGE_INTERVAL")
+	viper.BindEnv("trash.purge_batch", "TRASH_PURGE_BATCH")
+	viper.BindEnv("trash.integrity_interval", "TRASH_INTEGRITY_INTERVAL")
+}
+
+// Validate 验证回收站配置
+func (t *TrashConfig) Validate() error {
+	if t.Retention < 0 {
+		return fmt.Errorf("回收站保留时间不能为负数")
+	}
+	if t.Retention > 0 && t.PurgeInterval <= 0 {
+		return fmt.Errorf("回收站自动清理间隔必须大于0")
+	}
+	if t.PurgeBatch <= 0 {
+		return fmt.Errorf("回收站每次清理数量必须大于0")
+	}
+	if t.IntegrityInterval < 0 {
+		return fmt.Errorf("孤儿数据检查间隔不能为负数")
+	}
+	return nil
+}
+
+// GetTrashConfig 获取回收站配置
+func GetTrashConfig() *TrashConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.Trash
+}
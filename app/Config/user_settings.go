@@ -0,0 +1,70 @@
+package Config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	_ "time/tzdata" // 内置时区数据，系统未安装时区数据时也能校验时区
+
+	"github.com/spf13/viper"
+)
+
+// UserSettingsConfig 用户偏好设置的默认值
+// 功能说明：
+// 1. 用户未设置的偏好使用这里的默认值，修改默认值对所有未单独设置的用户生效
+// 2. 通知渠道开关决定向用户发送通知时使用哪些渠道，短信默认关闭，需要用户主动开启
+// 3. 语言默认使用 I18N_DEFAULT_LOCALE，可选语言为 I18N_SUPPORTED
+type UserSettingsConfig struct {
+	NotifyInApp             bool   `mapstructure:"notify_in_app"`             // 默认接收站内通知
+	NotifyEmail             bool   `mapstructure:"notify_email"`              // 默认接收邮件通知
+	NotifySMS               bool   `mapstructure:"notify_sms"`                // 默认接收短信通知
+	Timezone                string `mapstructure:"timezone"`                  // 默认时区（IANA时区名）
+	DashboardTimeRange      string `mapstructure:"dashboard_time_range"`      // 仪表板默认时间范围
+	DashboardRefreshSeconds int    `mapstructure:"dashboard_refresh_seconds"` // 仪表板默认自动刷新间隔（秒），0表示不自动刷新
+}
+
+// DashboardTimeRanges 仪表板可选的时间范围
+var DashboardTimeRanges = []string{"1h", "6h", "24h", "7d", "30d"}
+
+// SetDefaults 设置用户偏好默认值
+func (u *UserSettingsConfig) SetDefaults() {
+	viper.SetDefault("user_settings.notify_in_app", true)
+	viper.SetDefault("user_settings.notify_email", true)
+	viper.SetDefault("user_settings.notify_sms", false)
+	viper.SetDefault("user_settings.timezone", "Asia/Shanghai")
+	viper.SetDefault("user_settings.dashboard_time_range", "24h")
+	viper.SetDefault("user_settings.dashboard_refresh_seconds", 60)
+}
+
+// BindEnvs 绑定用户偏好环境变量
+func (u *UserSettingsConfig) BindEnvs() {
+	viper.BindEnv("user_settings.notify_in_app", "USER_SETTINGS_NOTIFY_IN_APP")
+	viper.BindEnv("user_settings.notify_email", "USER_SETTINGS_NOTIFY_EMAIL")
+	viper.BindEnv("user_settings.notify_sms", "USER_SETTINGS_NOTIFY_SMS")
+	viper.BindEnv("user_settings.timezone", "USER_SETTINGS_TIMEZONE")
+	viper.BindEnv("user_settings.dashboard_time_range", "USER_SETTINGS_DASHBOARD_TIME_RANGE")
+	viper.BindEnv("user_settings.dashboard_refresh_seconds", "USER_SETTINGS_DASHBOARD_REFRESH_SECONDS")
+}
+
+// Validate 验证用户偏好默认值
+func (u *UserSettingsConfig) Validate() error {
+	if _, err := time.LoadLocation(u.Timezone); u.Timezone == "" || err != nil {
+		return fmt.Errorf("默认时区无效: %s", u.Timezone)
+	}
+	if !slices.Contains(DashboardTimeRanges, u.DashboardTimeRange) {
+		return fmt.Errorf("仪表板默认时间范围无效: %s，可选值为 %s", u.DashboardTimeRange, strings.Join(DashboardTimeRanges, ","))
+	}
+	if u.DashboardRefreshSeconds < 0 || u.DashboardRefreshSeconds > 3600 {
+		return fmt.Errorf("仪表板自动刷新间隔必须在0到3600秒之间: %d", u.DashboardRefreshSeconds)
+	}
+	return nil
+}
+
+// GetUserSettingsConfig 获取用户偏好配置
+func GetUserSettingsConfig() *UserSettingsConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.UserSettings
+}
@@ -0,0 +1,86 @@
+package Config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// WebhookConfig 出站Webhook订阅配置
+// 功能说明：
+// 1. 集成方按事件类型订阅平台事件，事件总线投递事件后为每个匹配的订阅生成一条投递记录
+// 2. 投递记录由 Workers 个后台协程发送，请求体使用订阅密钥做HMAC-SHA256签名
+// 3. 发送失败按 RetryBackoff 指数退避重试（最长 MaxBackoff），超过 MaxAttempts 次后标记为失败
+// 4. 默认拒绝投递到内网和本机地址，避免订阅被用于访问内部服务
+type WebhookConfig struct {
+	Enabled              bool          `mapstructure:"enabled"`
+	Workers              int           `mapstructure:"workers"`                // 发送协程数
+	QueueSize            int           `mapstructure:"queue_size"`             // 发送队列长度，队列满时投递记录保留在数据库中稍后发送
+	Timeout              time.Duration `mapstructure:"timeout"`                // 单次请求超时
+	MaxAttempts          int           `mapstructure:"max_attempts"`           // 最大发送次数
+	RetryBackoff         time.Duration `mapstructure:"retry_backoff"`          // 首次重试等待时间，之后指数增长
+	MaxBackoff           time.Duration `mapstructure:"max_backoff"`            // 最长重试等待时间
+	Retention            time.Duration `mapstructure:"retention"`              // 投递记录保留时间
+	AllowPrivateNetworks bool          `mapstructure:"allow_private_networks"` // 是否允许投递到内网和本机地址
+}
+
+// SetDefaults 设置Webhook默认值
+func (w *WebhookConfig) SetDefaults() {
+	viper.SetDefault("webhooks.enabled", false)
+	viper.SetDefault("webhooks.workers", 4)
+	viper.SetDefault("webhooks.queue_size", 1000)
+	viper.SetDefault("webhooks.timeout", "10s")
+	viper.SetDefault("webhooks.max_attempts", 8)
+	viper.SetDefault("webhooks.retry_backoff", "30s")
+	viper.SetDefault("webhooks.max_backoff", "6h")
+	viper.SetDefault("webhooks.retention", "720h")
+	viper.SetDefault("webhooks.allow_private_networks", false)
+}
+
+// BindEnvs 绑定Webhook环境变量
+func (w *WebhookConfig) BindEnvs() {
+	viper.BindEnv("webhooks.enabled", "WEBHOOKS_ENABLED")
+	viper.BindEnv("webhooks.workers", "WEBHOOKS_WORKERS")
+	viper.BindEnv("webhooks.queue_size", "WEBHOOKS_QUEUE_SIZE")
+	viper.BindEnv("webhooks.timeout", "WEBHOOKS_TIMEOUT")
+	viper.BindEnv("webhooks.max_attempts", "WEBHOOKS_MAX_ATTEMPTS")
+	viper.BindEnv("webhooks.retry_backoff", "WEBHOOKS_RETRY_BACKOFF")
+	viper.BindEnv("webhooks.max_backoff", "WEBHOOKS_MAX_BACKOFF")
+	viper.BindEnv("webhooks.retention", "WEBHOOKS_RETENTION")
+	viper.BindEnv("webhooks.allow_private_networks", "WEBHOOKS_ALLOW_PRIVATE_NETWORKS")
+}
+
+// Validate 验证Webhook配置，未启用时不检查
+func (w *WebhookConfig) Validate() error {
+	if !w.Enabled {
+		return nil
+	}
+	if w.Workers <= 0 {
+		return fmt.Errorf("Webhook发送协程数必须大于0")
+	}
+	if w.QueueSize <= 0 {
+		return fmt.Errorf("Webhook发送队列长度必须大于0")
+	}
+	if w.Timeout <= 0 {
+		return fmt.Errorf("Webhook请求超时必须大于0")
+	}
+	if w.MaxAttempts <= 0 {
+		return fmt.Errorf("Webhook最大发送次数必须大于0")
+	}
+	if w.RetryBackoff <= 0 {
+		return fmt.Errorf("Webhook重试等待时间必须大于0")
+	}
+	if w.MaxBackoff < w.RetryBackoff {
+		return fmt.Errorf("Webhook最长重试等待时间不能小于首次重试等待时间")
+	}
+	return nil
+}
+
+// GetWebhookConfig 获取Webhook配置
+func GetWebhookConfig() *WebhookConfig {
+	if globalConfig == nil {
+		return nil
+	}
+	return &globalConfig.Webhooks
+}
@@ -0,0 +1,81 @@
+package Console
+
+import (
+	"cloud-platform-api/app/Services"
+	"flag"
+	"fmt"
+	"time"
+)
+
+// alertsCommand 告警命令
+func (a *Application) alertsCommand() *Command {
+	var skipCollect bool
+	return &Command{
+		Name:  "alerts",
+		Short: "告警规则",
+		Subcommands: []*Command{
+			{
+				Name:  "evaluate",
+				Short: "重新评估SLO和告警规则并输出结果",
+				Flags: func(fs *flag.FlagSet) {
+					fs.BoolVar(&skipCollect, "no-collect", false, "不执行本机指标采集，只评估SLO")
+				},
+				Run: func(args []string) error {
+					return a.evaluateAlerts(!skipCollect)
+				},
+			},
+		},
+	}
+}
+
+// evaluateAlerts 评估告警规则
+// 功能说明：
+// 1. 使用独立的监控核心注册数据库中全部SLO的消耗告警规则，按指标历史计算SLO并上报
+// 2. collect 为 true 时同时执行一轮本机指标采集，再由告警引擎评估全部规则
+// 3. 只输出评估结果，不发送告警通知；需要持续时间的规则在单次评估中不会触发
+func (a *Application) evaluateAlerts(collect bool) error {
+	db, err := a.database()
+	if err != nil {
+		return err
+	}
+	monitoringConfig := a.config().Monitoring
+
+	core := Services.NewMonitoringCore()
+	defer core.Close()
+	history := Services.NewMetricHistoryService(db, &monitoringConfig)
+	core.SetMetricHistory(history)
+
+	sloService := Services.NewMonitoringSLOService(db, &monitoringConfig)
+	sloService.SetMonitoringCore(core)
+	sloService.SetMetricHistory(history)
+	if err := sloService.SyncRules(); err != nil {
+		return fmt.Errorf("加载SLO告警规则失败: %v", err)
+	}
+
+	statuses := sloService.Evaluate(time.Now())
+	fmt.Fprintf(a.out, "SLO (%d):\n", len(statuses))
+	for _, status := range statuses {
+		result := "✅ 达成"
+		if !status.Met {
+			result = "❌ 未达成"
+		}
+		fmt.Fprintf(a.out, "  [%d] %s %s 达成率 %.3f%% (目标 %.3f%%), 剩余预算 %.2f%%\n",
+			status.SLOID, status.Name, result, status.Compliance, status.Objective, status.BudgetRemaining)
+	}
+
+	if collect {
+		err = core.Evaluate()
+	} else {
+		err = core.CheckAlerts()
+	}
+	if err != nil {
+		return fmt.Errorf("评估告警规则失败: %v", err)
+	}
+
+	alerts := core.Alerts("active", 0)
+	fmt.Fprintf(a.out, "规则: %d, 触发的告警: %d\n", len(core.Rules()), len(alerts))
+	for _, alert := range alerts {
+		fmt.Fprintf(a.out, "  [%s] %s: %s\n", alert.Level, alert.RuleID, alert.Message)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package Console
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Services"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Command 命令行命令
+// 功能说明：
+// 1. 有 Subcommands 的命令只用于分组，执行时按第一个参数选择子命令
+// 2. Flags 注册命令参数，参数值通过闭包传给 Run；Run 收到的是解析参数后剩余的位置参数
+type Command struct {
+	Name        string
+	Short       string // 简短说明，显示在帮助中
+	Usage       string // 位置参数说明，如 "<备份文件路径>"
+	Flags       func(fs *flag.FlagSet)
+	Run         func(args []string) error
+	Subcommands []*Command
+}
+
+// Application cloudctl 命令行应用
+// 功能说明：
+// 1. 提供数据库迁移、数据填充和演示环境初始化、管理员创建、JWT密钥轮换、备份恢复、配置快照比较、告警评估、缓存清理和日志跟踪等运维命令
+// 2. 提供 bench 压测命令，在CI中与基线比较发现性能回归；提供 sbom 命令生成内嵌的依赖清单
+// 3. 提供 make:controller、make:model 等代码生成命令
+// 4. 命令复用服务层实现，不直接执行SQL
+// 5. 配置和数据库连接在命令第一次需要时才加载，查看帮助、轮换JWT密钥、压测和生成代码不依赖数据库
+//
+// 注意事项：
+// - 命令行参数使用标准库 flag 解析，参数和位置参数可以交替书写
+// - 命令执行失败时退出码为1，用法错误时为2
+type Application struct {
+	in   io.Reader
+	out  io.Writer
+	err  io.Writer
+	db   *gorm.DB
+	root *Command
+}
+
+// NewApplication 创建命令行应用，out 和 errOut 分别为标准输出和错误输出
+func NewApplication(out, errOut io.Writer) *Application {
+	app := &Application{in: os.Stdin, out: out, err: errOut}
+	app.root = &Command{
+		Name:  "cloudctl",
+		Short: "云平台API运维命令行工具",
+		Subcommands: append([]*Command{
+			app.migrateCommand(),
+			app.seedCommand(),
+			app.bootstrapDemoCommand(),
+			app.userCommand(),
+			app.jwtCommand(),
+			app.backupCommand(),
+			app.alertsCommand(),
+			app.cacheCommand(),
+			app.configCommand(),
+			app.logsCommand(),
+			app.benchCommand(),
+			app.sbomCommand(),
+		}, app.makeCommands()...),
+	}
+	return app
+}
+
+// SetInput 设置交互输入，默认为标准输入
+func (a *Application) SetInput(in io.Reader) {
+	a.in = in
+}
+
+// SetDB 使用已有的数据库连接，不再加载配置和连接数据库（用于测试或嵌入其他程序）
+func (a *Application) SetDB(db *gorm.DB) {
+	a.db = db
+}
+
+// Run 执行命令，args 不包含程序名，返回进程退出码
+func (a *Application) Run(args []string) int {
+	cmd := a.root
+	path := []string{cmd.Name}
+	for len(args) > 0 {
+		sub := cmd.find(args[0])
+		if sub == nil {
+			break
+		}
+		cmd = sub
+		path = append(path, sub.Name)
+		args = args[1:]
+	}
+
+	if cmd.Run == nil {
+		if len(args) > 0 && args[0] != "help" && args[0] != "-h" && args[0] != "--help" {
+			fmt.Fprintf(a.err, "未知命令: %s %s\n\n", strings.Join(path, " "), args[0])
+			a.printHelp(a.err, cmd, path, nil)
+			return 2
+		}
+		a.printHelp(a.out, cmd, path, nil)
+		if len(args) == 0 && cmd == a.root {
+			return 2
+		}
+		return 0
+	}
+
+	fs := flag.NewFlagSet(strings.Join(path, " "), flag.ContinueOnError)
+	fs.SetOutput(a.err)
+	if cmd.Flags != nil {
+		cmd.Flags(fs)
+	}
+	fs.Usage = func() { a.printHelp(a.err, cmd, path, fs) }
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return 0
+			}
+			return 2
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		// 位置参数之后的参数继续解析
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	if err := cmd.Run(positional); err != nil {
+		fmt.Fprintf(a.err, "错误: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// find 查找子命令
+func (c *Command) find(name string) *Command {
+	for _, sub := range c.Subcommands {
+		if sub.Name == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+// printHelp 输出命令帮助
+func (a *Application) printHelp(w io.Writer, cmd *Command, path []string, fs *flag.FlagSet) {
+	if cmd.Short != "" {
+		fmt.Fprintf(w, "%s\n\n", cmd.Short)
+	}
+	if len(cmd.Subcommands) > 0 {
+		fmt.Fprintf(w, "用法: %s <命令> [参数]\n\n命令:\n", strings.Join(path, " "))
+		for _, sub := range cmd.Subcommands {
+			fmt.Fprintf(w, "  %-16s %s\n", sub.Name, sub.Short)
+		}
+		fmt.Fprintf(w, "\n使用 \"%s <命令> -h\" 查看命令帮助\n", strings.Join(path, " "))
+		return
+	}
+
+	usage := strings.Join(path, " ") + " [参数]"
+	if cmd.Usage != "" {
+		usage += " " + cmd.Usage
+	}
+	fmt.Fprintf(w, "用法: %s\n", usage)
+	if fs != nil {
+		fmt.Fprintln(w, "\n参数:")
+		fs.SetOutput(w)
+		fs.PrintDefaults()
+	}
+}
+
+// config 获取全局配置，未加载时从 .env 和环境变量加载
+func (a *Application) config() *Config.Config {
+	if Config.GetConfig() == nil {
+		Config.LoadConfig()
+	}
+	return Config.GetConfig()
+}
+
+// database 获取数据库连接，未设置时加载配置并连接数据库
+// 事件总线启用时设置全局事件总线但不启动，命令产生的领域事件写入发件箱，由运行中的服务投递
+func (a *Application) database() (*gorm.DB, error) {
+	if a.db != nil {
+		return a.db, nil
+	}
+	a.config()
+	Database.InitDB()
+	if Database.GetDB() == nil {
+		return nil, fmt.Errorf("数据库连接失败")
+	}
+	a.db = Database.GetDB()
+
+	if eventBusConfig := Config.GetEventBusConfig(); eventBusConfig != nil && eventBusConfig.Enabled {
+		Services.SetDefaultEventBus(Services.NewEventBus(a.db, *eventBusConfig))
+	}
+	return a.db, nil
+}
+
+// requireArgs 检查位置参数数量
+func requireArgs(args []string, n int, usage string) error {
+	if len(args) != n {
+		return fmt.Errorf("参数错误，用法: %s", usage)
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package Console
+
+import (
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// backupCommand 备份和恢复命令
+func (a *Application) backupCommand() *Command {
+	var (
+		backupType string
+		force      bool
+		output     string
+		escrowKey  string
+		tables     string
+		domains    string
+	)
+	return &Command{
+		Name:  "backup",
+		Short: "备份和恢复",
+		Subcommands: []*Command{
+			{
+				Name:  "create",
+				Short: "立即创建备份",
+				Flags: func(fs *flag.FlagSet) {
+					fs.StringVar(&backupType, "type", "database", "备份类型: database, files, full, tables")
+					fs.StringVar(&tables, "tables", "", "按表备份的表，逗号分隔（--type tables）")
+					fs.StringVar(&domains, "domains", "", "按表备份的领域，逗号分隔，如 security,monitoring（--type tables）")
+				},
+				Run: func(args []string) error {
+					service := a.backupService()
+					var (
+						info *Services.BackupInfo
+						err  error
+					)
+					switch backupType {
+					case "database":
+						info, err = service.CreateDatabaseBackup()
+					case "files":
+						info, err = service.CreateFileBackup()
+					case "full":
+						info, err = service.CreateFullBackup()
+					case "tables":
+						info, err = service.CreateTableBackup(context.Background(), backupSelection(tables, domains))
+					default:
+						return fmt.Errorf("不支持的备份类型: %s", backupType)
+					}
+					if err != nil {
+						return fmt.Errorf("备份失败: %v", err)
+					}
+					fmt.Fprintf(a.out, "✅ 备份完成: %s (%d 字节, MD5: %s)\n", info.Path, info.Size, info.MD5)
+					return nil
+				},
+			},
+			{
+				Name:  "list",
+				Short: "列出备份文件",
+				Run: func(args []string) error {
+					backups, err := a.backupService().ListBackups()
+					if err != nil {
+						return fmt.Errorf("获取备份列表失败: %v", err)
+					}
+					if len(backups) == 0 {
+						fmt.Fprintln(a.out, "暂无备份")
+						return nil
+					}
+					for _, backup := range backups {
+						fmt.Fprintf(a.out, "%-10s %-20s %12d  %s\n", backup.Type, backup.CreatedAt.Format(time.DateTime), backup.Size, backup.Path)
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "restore",
+				Short: "从备份文件恢复（会覆盖当前数据）；指定 --tables 或 --domains 时只恢复选择的表",
+				Usage: "<备份文件路径>",
+				Flags: func(fs *flag.FlagSet) {
+					fs.StringVar(&backupType, "type", "database", "备份类型: database, files, full, tables")
+					fs.StringVar(&tables, "tables", "", "只恢复的表，逗号分隔")
+					fs.StringVar(&domains, "domains", "", "只恢复的领域，逗号分隔")
+					fs.BoolVar(&force, "force", false, "确认覆盖当前数据")
+				},
+				Run: func(args []string) error {
+					if err := requireArgs(args, 1, "cloudctl backup restore [参数] <备份文件路径>"); err != nil {
+						return err
+					}
+					if !force {
+						return fmt.Errorf("恢复会覆盖当前数据，请停止服务后使用 --force 确认")
+					}
+					if selection := backupSelection(tables, domains); !selection.IsEmpty() {
+						result, err := a.backupService().RestoreSelected(context.Background(), args[0], selection)
+						if err != nil {
+							return fmt.Errorf("恢复失败: %v", err)
+						}
+						fmt.Fprintf(a.out, "✅ 已从 %s 恢复 %d 个表 (%d ms)\n", args[0], len(result.Tables), result.DurationMS)
+						for table, count := range result.Tables {
+							fmt.Fprintf(a.out, "  %-30s %d\n", table, count)
+						}
+						return nil
+					}
+					if err := a.backupService().RestoreBackup(args[0], backupType); err != nil {
+						return fmt.Errorf("恢复失败: %v", err)
+					}
+					fmt.Fprintf(a.out, "✅ 已从 %s 恢复\n", args[0])
+					return nil
+				},
+			},
+			{
+				Name:  "verify",
+				Short: "校验备份文件的校验和与压缩包完整性",
+				Usage: "<备份文件路径>",
+				Run: func(args []string) error {
+					if err := requireArgs(args, 1, "cloudctl backup verify <备份文件路径>"); err != nil {
+						return err
+					}
+					verification, err := a.backupService().VerifyBackup(args[0])
+					if err != nil {
+						return fmt.Errorf("校验失败: %v", err)
+					}
+					fmt.Fprintf(a.out, "✅ 校验通过: %s (SHA-256: %s)\n", verification.Path, verification.SHA256)
+					return nil
+				},
+			},
+			{
+				Name:  "decrypt",
+				Short: "解密加密的备份文件；主密钥不可用时可使用托管私钥",
+				Usage: "<备份文件路径>",
+				Flags: func(fs *flag.FlagSet) {
+					fs.StringVar(&output, "output", "", "解密后的文件路径，默认为去掉 .enc 的原文件名")
+					fs.StringVar(&escrowKey, "escrow-key", "", "托管私钥PEM文件，指定时不使用主密钥")
+				},
+				Run: func(args []string) error {
+					if err := requireArgs(args, 1, "cloudctl backup decrypt [参数] <备份文件路径>"); err != nil {
+						return err
+					}
+					if !Services.IsEncryptedBackup(args[0]) {
+						return Services.ErrBackupNotEncrypted
+					}
+					target := output
+					if target == "" {
+						target = strings.TrimSuffix(args[0], ".enc")
+					}
+					var err error
+					if escrowKey != "" {
+						privateKey, keyErr := Services.LoadEscrowPrivateKey(escrowKey)
+						if keyErr != nil {
+							return keyErr
+						}
+						err = Services.DecryptBackupWithEscrow(args[0], target, privateKey)
+					} else {
+						err = a.backupService().DecryptBackup(context.Background(), args[0], target)
+					}
+					if err != nil {
+						return fmt.Errorf("解密失败: %v", err)
+					}
+					fmt.Fprintf(a.out, "✅ 已解密到 %s\n", target)
+					return nil
+				},
+			},
+			{
+				Name:  "manifest",
+				Short: "显示备份清单：包含的表、行数、领域和文件数",
+				Usage: "<备份文件路径>",
+				Run: func(args []string) error {
+					if err := requireArgs(args, 1, "cloudctl backup manifest <备份文件路径>"); err != nil {
+						return err
+					}
+					manifest, err := a.backupService().ReadBackupManifest(args[0])
+					if err != nil {
+						return fmt.Errorf("读取清单失败: %v", err)
+					}
+					fmt.Fprintf(a.out, "%s (%s, %s) 创建于 %s\n", manifest.BackupID, manifest.Type, manifest.Driver, manifest.CreatedAt.Format(time.DateTime))
+					for _, table := range manifest.Tables {
+						fmt.Fprintf(a.out, "  %-30s %10d  %s\n", table.Name, table.Rows, strings.Join(table.Domains, ","))
+					}
+					if manifest.Files > 0 {
+						fmt.Fprintf(a.out, "  文件: %d\n", manifest.Files)
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "drill",
+				Short: "立即运行恢复演练：将最新的数据库备份恢复到临时数据库并执行冒烟查询",
+				Run: func(args []string) error {
+					result, err := a.backupService().RunRestoreDrill(context.Background())
+					if err != nil {
+						return fmt.Errorf("恢复演练失败: %v", err)
+					}
+					fmt.Fprintf(a.out, "✅ 恢复演练成功: %s (%d ms)\n", result.BackupPath, result.DurationMS)
+					for table, count := range result.Tables {
+						fmt.Fprintf(a.out, "  %-30s %d\n", table, count)
+					}
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// backupService 按存储配置创建备份服务，与应用容器中的备份服务使用相同配置，但不启动自动备份和定时恢复演练
+func (a *Application) backupService() *Services.BackupService {
+	storageConfig := a.config().Storage
+	backupConfig := Services.NewBackupConfigFromStorage(&storageConfig)
+	backupConfig.EnableAutoBackup = false
+	backupConfig.RestoreDrillEnabled = false
+	return Services.NewBackupService(Storage.NewStorageManager(&storageConfig), backupConfig)
+}
+
+// backupSelection 解析逗号分隔的表和领域参数
+func backupSelection(tables, domains string) Services.BackupSelection {
+	var selection Services.BackupSelection
+	for _, table := range strings.Split(tables, ",") {
+		if table = strings.TrimSpace(table); table != "" {
+			selection.Tables = append(selection.Tables, table)
+		}
+	}
+	for _, domain := range strings.Split(domains, ",") {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			selection.Domains = append(selection.Domains, domain)
+		}
+	}
+	return selection
+}
@@ -0,0 +1,197 @@
+package Console
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Testing/LoadTest"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// benchPasswordEnv 压测账号密码的环境变量，避免密码出现在进程列表和CI日志中
+const benchPasswordEnv = "CLOUDCTL_BENCH_PASSWORD"
+
+// benchOptions 压测命令参数
+type benchOptions struct {
+	url            string
+	username       string
+	password       string
+	scenarios      string
+	load           LoadTest.Options
+	gate           LoadTest.GateOptions
+	baseline       string
+	output         string
+	updateBaseline bool
+}
+
+// benchCommand 压测命令
+func (a *Application) benchCommand() *Command {
+	var options benchOptions
+	return &Command{
+		Name:  "bench",
+		Short: "压测关键接口，P95 相对基线退化时失败（用于CI）",
+		Flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&options.url, "url", "http://127.0.0.1:8080", "服务地址")
+			fs.StringVar(&options.username, "username", "", "登录账号，为空时跳过登录场景，指标和告警接口不携带令牌")
+			fs.StringVar(&options.password, "password", "", "登录密码，为空时读取环境变量 "+benchPasswordEnv)
+			fs.StringVar(&options.scenarios, "scenarios", "login,metrics,alerts", "压测场景，逗号分隔")
+			fs.IntVar(&options.load.Requests, "requests", 200, "每个场景的请求数")
+			fs.IntVar(&options.load.Concurrency, "concurrency", 10, "并发数")
+			fs.IntVar(&options.load.Warmup, "warmup", 10, "每个场景的预热请求数，不计入统计")
+			fs.DurationVar(&options.load.Timeout, "timeout", 10*time.Second, "单个请求的超时时间")
+			fs.StringVar(&options.baseline, "baseline", "testdata/perf-baseline.json", "基线文件")
+			fs.Float64Var(&options.gate.Tolerance, "tolerance", 0.2, "P95 允许超出基线的比例")
+			fs.DurationVar(&options.gate.MinDelta, "min-delta", 5*time.Millisecond, "P95 超出基线不到该值时不视为回归")
+			fs.Float64Var(&options.gate.MaxErrorRate, "max-error-rate", 0, "允许的请求失败率")
+			fs.StringVar(&options.output, "output", "", "把本次压测报告写入该文件")
+			fs.BoolVar(&options.updateBaseline, "update-baseline", false, "把本次结果写入基线文件，不做回归检查")
+		},
+		Run: func(args []string) error {
+			if options.password == "" {
+				options.password = os.Getenv(benchPasswordEnv)
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return a.bench(ctx, options)
+		},
+	}
+}
+
+// bench 压测关键接口并与基线比较
+// 功能说明：
+// 1. 指定账号时先登录获取令牌，指标和告警接口携带令牌访问
+// 2. 按场景依次压测并输出延迟分位数、吞吐量和失败率
+// 3. --update-baseline 时写入基线文件；否则与基线比较，存在回归时返回错误（退出码为1）
+func (a *Application) bench(ctx context.Context, options benchOptions) error {
+	var names []string
+	for _, name := range strings.Split(options.scenarios, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if name == LoadTest.ScenarioLogin && options.username == "" {
+			fmt.Fprintln(a.out, "未指定 --username，跳过登录场景")
+			continue
+		}
+		if len(LoadTest.KeyScenarios(LoadTest.Credentials{}, "", name)) == 0 {
+			return fmt.Errorf("未知的压测场景: %s", name)
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("没有要执行的压测场景")
+	}
+
+	credentials := LoadTest.Credentials{Username: options.username, Password: options.password}
+	var token string
+	if options.username != "" {
+		var err error
+		if token, err = benchLogin(ctx, options.url, credentials, options.load.Timeout); err != nil {
+			return err
+		}
+	}
+
+	// 压测前读取基线，基线文件缺失时不必等待压测完成
+	var baseline *LoadTest.Report
+	if !options.updateBaseline {
+		var err error
+		baseline, err = LoadTest.LoadReport(options.baseline)
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("基线文件 %s 不存在，使用 --update-baseline 生成", options.baseline)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	load := options.load
+	if load.Warmup == 0 {
+		load.Warmup = -1 // 命令行的 0 表示不预热
+	}
+	report := LoadTest.NewRemoteGenerator(options.url, load).Run(ctx, LoadTest.KeyScenarios(credentials, token, names...)...)
+	a.printBenchReport(report, baseline)
+
+	if options.output != "" {
+		if err := report.WriteFile(options.output); err != nil {
+			return fmt.Errorf("写入压测报告失败: %v", err)
+		}
+	}
+	if options.updateBaseline {
+		if err := report.WriteFile(options.baseline); err != nil {
+			return fmt.Errorf("写入基线文件失败: %v", err)
+		}
+		fmt.Fprintf(a.out, "已更新基线文件 %s\n", options.baseline)
+		return nil
+	}
+
+	regressions := report.Compare(baseline, options.gate)
+	if len(regressions) == 0 {
+		fmt.Fprintln(a.out, "未发现性能回归")
+		return nil
+	}
+	fmt.Fprintln(a.out, "性能回归:")
+	for _, regression := range regressions {
+		fmt.Fprintf(a.out, "  %s\n", regression)
+	}
+	return fmt.Errorf("发现 %d 项性能回归", len(regressions))
+}
+
+// printBenchReport 输出压测结果，有基线时同时输出基线 P95
+func (a *Application) printBenchReport(report, baseline *LoadTest.Report) {
+	fmt.Fprintf(a.out, "%-10s %8s %6s %10s %10s %10s %10s %10s %12s\n",
+		"场景", "请求数", "失败", "P50", "P95", "P99", "最大", "基线P95", "吞吐量(次/秒)")
+	for _, result := range report.Scenarios {
+		base := "-"
+		if baseline != nil {
+			if scenario := baseline.Scenario(result.Name); scenario != nil {
+				base = formatLatency(scenario.P95)
+			}
+		}
+		fmt.Fprintf(a.out, "%-10s %8d %6d %10s %10s %10s %10s %10s %12.1f\n",
+			result.Name, result.Requests, result.Errors, formatLatency(result.P50), formatLatency(result.P95),
+			formatLatency(result.P99), formatLatency(result.Max), base, result.Throughput)
+		if result.LastError != "" {
+			fmt.Fprintf(a.out, "  最近一次失败: %s\n", result.LastError)
+		}
+	}
+}
+
+// formatLatency 延迟保留到 0.01 毫秒
+func formatLatency(d time.Duration) string {
+	return d.Round(10 * time.Microsecond).String()
+}
+
+// benchLogin 登录获取访问令牌
+func benchLogin(ctx context.Context, baseURL string, credentials LoadTest.Credentials, timeout time.Duration) (string, error) {
+	body, _ := json.Marshal(map[string]string{"username": credentials.Username, "password": credentials.Password})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(baseURL, "/")+"/api/v1/auth/login", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := (&http.Client{Timeout: timeout}).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("登录失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Message string `json:"message"`
+		Data    struct {
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("解析登录响应失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || result.Data.Token == "" {
+		return "", fmt.Errorf("登录失败（状态码 %d）: %s", resp.StatusCode, result.Message)
+	}
+	return result.Data.Token, nil
+}
@@ -0,0 +1,49 @@
+package Console
+
+import (
+	"cloud-platform-api/app/Services"
+	"flag"
+	"fmt"
+)
+
+// cacheCommand 缓存命令
+func (a *Application) cacheCommand() *Command {
+	var force bool
+	return &Command{
+		Name:  "cache",
+		Short: "缓存管理",
+		Subcommands: []*Command{
+			{
+				Name:  "flush",
+				Short: "清空Redis缓存（各服务进程内的内存缓存不受影响）",
+				Flags: func(fs *flag.FlagSet) {
+					fs.BoolVar(&force, "force", false, "确认清空当前Redis库")
+				},
+				Run: func(args []string) error {
+					if !force {
+						return fmt.Errorf("该操作会清空当前Redis库中的全部键（包括令牌黑名单等），请使用 --force 确认")
+					}
+					redisConfig := a.config().Redis
+					if redisConfig.Host == "" {
+						return fmt.Errorf("未配置Redis")
+					}
+					redisService := Services.NewRedisService(&Services.RedisConfig{
+						Host:     redisConfig.Host,
+						Port:     redisConfig.Port,
+						Password: redisConfig.Password,
+						DB:       redisConfig.Database,
+					})
+					defer redisService.Close()
+					if err := redisService.Ping(); err != nil {
+						return fmt.Errorf("Redis连接失败: %v", err)
+					}
+					if err := redisService.Clear(); err != nil {
+						return fmt.Errorf("清空缓存失败: %v", err)
+					}
+					fmt.Fprintf(a.out, "✅ 已清空Redis库 %s:%d/%d\n", redisConfig.Host, redisConfig.Port, redisConfig.Database)
+					return nil
+				},
+			},
+		},
+	}
+}
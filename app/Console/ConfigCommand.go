@@ -0,0 +1,177 @@
+package Console
+
+import (
+	"cloud-platform-api/app/Services"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// configCommand 配置命令
+func (a *Application) configCommand() *Command {
+	var (
+		asJSON      bool
+		label       string
+		environment string
+		ignore      string
+	)
+	return &Command{
+		Name:  "config",
+		Short: "有效配置查看、快照和差异比较",
+		Subcommands: []*Command{
+			{
+				Name:  "show",
+				Short: "显示当前脱敏后的有效配置",
+				Flags: func(fs *flag.FlagSet) {
+					fs.BoolVar(&asJSON, "json", false, "输出JSON，可通过 /api/v1/admin/config/snapshots/import 导入其他环境比较")
+				},
+				Run: func(args []string) error {
+					a.config()
+					values, err := Services.NewConfigSnapshotService(nil).CurrentValues()
+					if err != nil {
+						return err
+					}
+					if asJSON {
+						data, err := json.MarshalIndent(values, "", "  ")
+						if err != nil {
+							return err
+						}
+						fmt.Fprintln(a.out, string(data))
+						return nil
+					}
+					keys := make([]string, 0, len(values))
+					for key := range values {
+						keys = append(keys, key)
+					}
+					sort.Strings(keys)
+					for _, key := range keys {
+						fmt.Fprintf(a.out, "%s = %s\n", key, formatConfigValue(values[key]))
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "snapshot",
+				Short: "为当前配置生成快照",
+				Flags: func(fs *flag.FlagSet) {
+					fs.StringVar(&label, "label", "", "快照说明，如发布版本")
+					fs.StringVar(&environment, "env", "", "环境，默认为 APP_ENV")
+				},
+				Run: func(args []string) error {
+					service, err := a.configSnapshotService()
+					if err != nil {
+						return err
+					}
+					snapshot, err := service.Capture(Services.ConfigSnapshotInput{Environment: environment, Label: label}, 0)
+					if err != nil {
+						return fmt.Errorf("生成配置快照失败: %v", err)
+					}
+					fmt.Fprintf(a.out, "✅ 已生成配置快照 #%d: %s v%d (%d 项, %s)\n", snapshot.ID, snapshot.Environment, snapshot.Version, snapshot.KeyCount, snapshot.Checksum[:12])
+					return nil
+				},
+			},
+			{
+				Name:  "snapshots",
+				Short: "列出配置快照",
+				Flags: func(fs *flag.FlagSet) {
+					fs.StringVar(&environment, "env", "", "只列出该环境的快照")
+				},
+				Run: func(args []string) error {
+					service, err := a.configSnapshotService()
+					if err != nil {
+						return err
+					}
+					snapshots, err := service.List(environment, 0)
+					if err != nil {
+						return fmt.Errorf("获取配置快照失败: %v", err)
+					}
+					if len(snapshots) == 0 {
+						fmt.Fprintln(a.out, "暂无配置快照")
+						return nil
+					}
+					for _, snapshot := range snapshots {
+						fmt.Fprintf(a.out, "#%-5d %-12s v%-4d %-8s %-20s %s  %s\n", snapshot.ID, snapshot.Environment, snapshot.Version,
+							snapshot.Source, snapshot.CreatedAt.Format(time.DateTime), snapshot.Checksum[:12], snapshot.Label)
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "diff",
+				Short: "比较两个配置快照，省略第二个快照时与当前配置比较",
+				Usage: "<快照ID> [快照ID]",
+				Flags: func(fs *flag.FlagSet) {
+					fs.StringVar(&ignore, "ignore", "", "忽略的键前缀，逗号分隔，如 server.port,log")
+				},
+				Run: func(args []string) error {
+					if len(args) != 1 && len(args) != 2 {
+						return fmt.Errorf("参数错误，用法: cloudctl config diff [参数] <快照ID> [快照ID]")
+					}
+					ids := make([]uint, 2)
+					for i, arg := range args {
+						id, err := strconv.ParseUint(arg, 10, 32)
+						if err != nil || id == 0 {
+							return fmt.Errorf("无效的快照ID: %s", arg)
+						}
+						ids[i] = uint(id)
+					}
+					service, err := a.configSnapshotService()
+					if err != nil {
+						return err
+					}
+					var prefixes []string
+					for _, prefix := range strings.Split(ignore, ",") {
+						if prefix = strings.TrimSpace(prefix); prefix != "" {
+							prefixes = append(prefixes, prefix)
+						}
+					}
+					diff, err := service.Diff(ids[0], ids[1], prefixes)
+					if err != nil {
+						return fmt.Errorf("比较配置失败: %v", err)
+					}
+					if len(diff.Changes) == 0 {
+						fmt.Fprintln(a.out, "✅ 配置一致")
+						return nil
+					}
+					for _, change := range diff.Changes {
+						switch change.Type {
+						case Services.ConfigChangeAdded:
+							fmt.Fprintf(a.out, "+ %s = %s\n", change.Key, formatConfigValue(change.To))
+						case Services.ConfigChangeRemoved:
+							fmt.Fprintf(a.out, "- %s = %s\n", change.Key, formatConfigValue(change.From))
+						default:
+							fmt.Fprintf(a.out, "~ %s: %s -> %s\n", change.Key, formatConfigValue(change.From), formatConfigValue(change.To))
+						}
+					}
+					fmt.Fprintf(a.out, "新增 %d，删除 %d，修改 %d\n", diff.Added, diff.Removed, diff.Changed)
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// configSnapshotService 创建配置快照服务，快照的是当前 .env 和环境变量中的配置
+func (a *Application) configSnapshotService() (*Services.ConfigSnapshotService, error) {
+	a.config()
+	db, err := a.database()
+	if err != nil {
+		return nil, err
+	}
+	return Services.NewConfigSnapshotService(db), nil
+}
+
+// formatConfigValue 格式化配置值，字符串和数组按JSON输出以区分空值
+func formatConfigValue(value interface{}) string {
+	switch v := value.(type) {
+	case string, []interface{}, nil:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,100 @@
+package Console
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Services"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// logsCommand 日志命令
+func (a *Application) logsCommand() *Command {
+	var (
+		logger   string
+		lines    int
+		follow   bool
+		minLevel string
+		text     string
+	)
+	return &Command{
+		Name:  "logs",
+		Short: "日志查看",
+		Subcommands: []*Command{
+			{
+				Name:  "tail",
+				Short: "输出最近的日志，可持续跟踪新增内容",
+				Flags: func(fs *flag.FlagSet) {
+					fs.StringVar(&logger, "logger", "error", "日志记录器: request, sql, error, audit, security, business, access")
+					fs.IntVar(&lines, "n", 20, "输出最近的日志条数")
+					fs.BoolVar(&follow, "f", false, "持续跟踪新增日志，按 Ctrl+C 退出")
+					fs.StringVar(&minLevel, "level", "", "最低日志级别: debug, info, warning, error, fatal")
+					fs.StringVar(&text, "grep", "", "只输出包含该关键词的日志（不区分大小写）")
+				},
+				Run: func(args []string) error {
+					query := Services.LogQuery{
+						Loggers:  []string{logger},
+						MinLevel: Config.LogLevel(minLevel),
+						Text:     text,
+						PageSize: lines,
+					}
+					ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+					defer stop()
+					return a.tailLogs(ctx, query, follow)
+				},
+			},
+		},
+	}
+}
+
+// tailLogs 按时间顺序输出最近的日志，follow 为 true 时继续输出新增日志直到 ctx 取消
+func (a *Application) tailLogs(ctx context.Context, query Services.LogQuery, follow bool) error {
+	service := Services.NewLogQueryService(&a.config().Log)
+	logger := query.Loggers[0]
+	if !service.HasLogger(logger) {
+		return fmt.Errorf("日志记录器不存在或未启用: %s", logger)
+	}
+
+	if query.PageSize > 0 {
+		result, err := service.Search(ctx, query)
+		if err != nil {
+			return fmt.Errorf("读取日志失败: %v", err)
+		}
+		// 查询结果按时间倒序，输出时反转为时间顺序
+		slices.Reverse(result.Entries)
+		for _, entry := range result.Entries {
+			a.printLogEntry(entry)
+		}
+	}
+	if !follow {
+		return nil
+	}
+
+	entries, err := service.Tail(ctx, logger, query)
+	if err != nil {
+		return err
+	}
+	for entry := range entries {
+		a.printLogEntry(entry)
+	}
+	return nil
+}
+
+// printLogEntry 输出一条日志，非JSON格式的日志行原样输出
+func (a *Application) printLogEntry(entry Services.LogEntry) {
+	if entry.Timestamp.IsZero() {
+		fmt.Fprintln(a.out, entry.Message)
+		return
+	}
+	line := fmt.Sprintf("%s %-7s %s", entry.Timestamp.Format(time.DateTime), strings.ToUpper(string(entry.Level)), entry.Message)
+	if entry.TraceID != "" {
+		line += " trace_id=" + entry.TraceID
+	}
+	fmt.Fprintln(a.out, line)
+}
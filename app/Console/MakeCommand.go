@@ -0,0 +1,467 @@
+package Console
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+//go:embed stubs/*.stub
+var stubs embed.FS
+
+const (
+	// routesMarker routes.go 中生成的路由注册插入位置
+	routesMarker = "// cloudctl:routes"
+	// migrationNamePrefix 迁移名称的日期前缀，序号在已有迁移的基础上递增
+	migrationNamePrefix = "2024_01_01_"
+)
+
+// 生成文件所在目录，相对于项目根目录
+var (
+	modelsDir      = filepath.Join("app", "Models")
+	migrationsDir  = filepath.Join("app", "Database", "Migrations")
+	servicesDir    = filepath.Join("app", "Services")
+	controllersDir = filepath.Join("app", "Http", "Controllers")
+	routesDir      = filepath.Join("app", "Http", "Routes")
+	middlewareDir  = filepath.Join("app", "Http", "Middleware")
+	policiesDir    = filepath.Join("app", "Policies")
+)
+
+// scaffold 代码模板参数
+type scaffold struct {
+	Name          string // 类型名，如 ArticleTag
+	Label         string // 中文名称，用于注释和提示信息
+	Plural        string // 复数类型名，如 ArticleTags
+	Var           string // 变量名，如 articleTag
+	VarPlural     string // 复数变量名，如 articleTags
+	Snake         string // 下划线名称，如 article_tag
+	Table         string // 表名，如 article_tags
+	RoutePath     string // 路由路径，如 article-tags
+	Migration     string // 迁移类型名，如 CreateArticleTagsTable
+	MigrationName string // 迁移名称，如 2024_01_01_000024_create_article_tags_table
+	Model         string // 策略关联的模型
+}
+
+// generator 代码生成器
+type generator struct {
+	app   *Application
+	root  string // 项目根目录
+	force bool   // 覆盖已存在的文件
+}
+
+// makeCommands 代码生成命令
+// 功能说明：
+// 1. 按项目约定的目录、包名和中文注释风格生成模型、迁移、服务、控制器、中间件和策略
+// 2. 迁移自动加入迁移列表，资源控制器自动生成路由文件并在 routes.go 中注册
+// 3. 未指定名称时从标准输入读取；已存在的文件不会被覆盖，除非使用 --force
+func (a *Application) makeCommands() []*Command {
+	g := &generator{app: a}
+	var (
+		label      string
+		migration  bool
+		resource   bool
+		model      string
+		skipRoutes bool
+	)
+	common := func(fs *flag.FlagSet) {
+		fs.StringVar(&g.root, "dir", ".", "项目根目录")
+		fs.StringVar(&label, "label", "", "中文名称，用于注释和提示信息，默认使用类型名")
+		fs.BoolVar(&g.force, "force", false, "覆盖已存在的文件")
+	}
+
+	return []*Command{
+		{
+			Name:  "make:model",
+			Short: "生成模型（可同时生成迁移）",
+			Usage: "<名称>",
+			Flags: func(fs *flag.FlagSet) {
+				common(fs)
+				fs.BoolVar(&migration, "m", false, "同时生成建表迁移并加入迁移列表")
+			},
+			Run: func(args []string) error {
+				s, err := g.scaffold(args, "模型名称", "", label)
+				if err != nil {
+					return err
+				}
+				return g.makeModel(s, migration)
+			},
+		},
+		{
+			Name:  "make:service",
+			Short: "生成服务",
+			Usage: "<名称>",
+			Flags: func(fs *flag.FlagSet) {
+				common(fs)
+				fs.BoolVar(&resource, "resource", false, "生成同名模型的增删改查方法")
+			},
+			Run: func(args []string) error {
+				s, err := g.scaffold(args, "服务名称", "Service", label)
+				if err != nil {
+					return err
+				}
+				return g.makeService(s, resource)
+			},
+		},
+		{
+			Name:  "make:controller",
+			Short: "生成控制器（资源控制器同时生成服务和路由）",
+			Usage: "<名称>",
+			Flags: func(fs *flag.FlagSet) {
+				common(fs)
+				fs.BoolVar(&resource, "resource", false, "生成同名模型的增删改查接口、服务和管理员路由")
+				fs.BoolVar(&skipRoutes, "no-routes", false, "资源控制器不在 routes.go 中注册路由")
+			},
+			Run: func(args []string) error {
+				s, err := g.scaffold(args, "控制器名称", "Controller", label)
+				if err != nil {
+					return err
+				}
+				return g.makeController(s, resource, !skipRoutes)
+			},
+		},
+		{
+			Name:  "make:middleware",
+			Short: "生成中间件",
+			Usage: "<名称>",
+			Flags: common,
+			Run: func(args []string) error {
+				s, err := g.scaffold(args, "中间件名称", "Middleware", label)
+				if err != nil {
+					return err
+				}
+				return g.write(filepath.Join(middlewareDir, s.Name+"Middleware.go"), "middleware.stub", s)
+			},
+		},
+		{
+			Name:  "make:policy",
+			Short: "生成授权策略",
+			Usage: "<名称>",
+			Flags: func(fs *flag.FlagSet) {
+				common(fs)
+				fs.StringVar(&model, "model", "", "关联的模型，生成 ViewAny/View/Create/Update/Delete 方法")
+			},
+			Run: func(args []string) error {
+				s, err := g.scaffold(args, "策略名称", "Policy", label)
+				if err != nil {
+					return err
+				}
+				if model != "" {
+					s.Model = pascalCase(model)
+					if !g.modelExists(s.Model) {
+						return fmt.Errorf("模型 Models.%s 不存在，请先执行 make:model %s", s.Model, s.Model)
+					}
+					s.Var = lowerFirst(s.Model)
+				}
+				return g.write(filepath.Join(policiesDir, s.Name+"Policy.go"), "policy.stub", s)
+			},
+		},
+	}
+}
+
+// scaffold 解析名称并计算模板参数，未指定名称时从标准输入读取
+func (g *generator) scaffold(args []string, prompt, suffix, label string) (*scaffold, error) {
+	if len(args) > 1 {
+		return nil, fmt.Errorf("只能指定一个名称")
+	}
+	name := ""
+	if len(args) == 1 {
+		name = args[0]
+	} else {
+		fmt.Fprintf(g.app.out, "%s: ", prompt)
+		line, _ := bufio.NewReader(g.app.in).ReadString('\n')
+		name = strings.TrimSpace(line)
+	}
+
+	name = strings.TrimSuffix(pascalCase(name), suffix)
+	if !regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`).MatchString(name) {
+		return nil, fmt.Errorf("名称无效: %q，只能包含字母和数字且以字母开头", name)
+	}
+	if label == "" {
+		label = name
+	}
+
+	snake := snakeCase(name)
+	plural := pluralize(name)
+	table := snakeCase(plural)
+	return &scaffold{
+		Name:      name,
+		Label:     label,
+		Plural:    plural,
+		Var:       lowerFirst(name),
+		VarPlural: lowerFirst(plural),
+		Snake:     snake,
+		Table:     table,
+		RoutePath: strings.ReplaceAll(table, "_", "-"),
+		Migration: "Create" + plural + "Table",
+	}, nil
+}
+
+// makeModel 生成模型，migration 为 true 时同时生成迁移并加入迁移列表
+func (g *generator) makeModel(s *scaffold, migration bool) error {
+	if err := g.write(filepath.Join(modelsDir, s.Name+".go"), "model.stub", s); err != nil {
+		return err
+	}
+	if !migration {
+		return nil
+	}
+
+	sequence, err := g.nextMigrationSequence()
+	if err != nil {
+		return err
+	}
+	s.MigrationName = fmt.Sprintf("%s%06d_create_%s_table", migrationNamePrefix, sequence, s.Table)
+	if err := g.write(filepath.Join(migrationsDir, s.Migration+".go"), "migration.stub", s); err != nil {
+		return err
+	}
+	return g.registerMigration(s)
+}
+
+// makeService 生成服务，resource 为 true 时生成同名模型的增删改查方法
+func (g *generator) makeService(s *scaffold, resource bool) error {
+	if !resource {
+		return g.write(filepath.Join(servicesDir, s.Name+"Service.go"), "service.stub", s)
+	}
+	if !g.modelExists(s.Name) {
+		return fmt.Errorf("模型 Models.%s 不存在，请先执行 make:model %s -m", s.Name, s.Name)
+	}
+	return g.write(filepath.Join(servicesDir, s.Name+"Service.go"), "service.resource.stub", s)
+}
+
+// makeController 生成控制器
+// 资源控制器依赖同名模型，服务不存在时一并生成；registerRoutes 为 true 时在 routes.go 中注册路由
+func (g *generator) makeController(s *scaffold, resource, registerRoutes bool) error {
+	if !resource {
+		if err := g.write(filepath.Join(controllersDir, s.Name+"Controller.go"), "controller.stub", s); err != nil {
+			return err
+		}
+		fmt.Fprintf(g.app.out, "请在 routes.go 中为 %sController 注册路由\n", s.Name)
+		return nil
+	}
+
+	if !g.modelExists(s.Name) {
+		return fmt.Errorf("模型 Models.%s 不存在，请先执行 make:model %s -m", s.Name, s.Name)
+	}
+	servicePath := filepath.Join(servicesDir, s.Name+"Service.go")
+	if _, err := os.Stat(filepath.Join(g.root, servicePath)); os.IsNotExist(err) {
+		if err := g.write(servicePath, "service.resource.stub", s); err != nil {
+			return err
+		}
+	}
+	if err := g.write(filepath.Join(controllersDir, s.Name+"Controller.go"), "controller.resource.stub", s); err != nil {
+		return err
+	}
+	if err := g.write(filepath.Join(routesDir, s.Snake+".go"), "routes.stub", s); err != nil {
+		return err
+	}
+	if !registerRoutes {
+		return nil
+	}
+	return g.registerRoutes(s)
+}
+
+// write 渲染模板、格式化后写入文件，文件已存在且未使用 --force 时返回错误
+func (g *generator) write(path, stub string, s *scaffold) error {
+	fullPath := filepath.Join(g.root, path)
+	if _, err := os.Stat(fullPath); err == nil && !g.force {
+		return fmt.Errorf("文件已存在: %s，使用 --force 覆盖", path)
+	}
+
+	tmpl, err := template.ParseFS(stubs, "stubs/"+stub)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, s); err != nil {
+		return fmt.Errorf("渲染模板失败: %v", err)
+	}
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("格式化生成的代码失败: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(fullPath, source, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(g.app.out, "✅ 已生成 %s\n", path)
+	return nil
+}
+
+// modelExists 检查模型是否已定义，模型可能与其他模型定义在同一文件中
+func (g *generator) modelExists(name string) bool {
+	files, _ := filepath.Glob(filepath.Join(g.root, modelsDir, "*.go"))
+	pattern := regexp.MustCompile(`(?m)^type ` + name + ` struct\b`)
+	for _, file := range files {
+		if data, err := os.ReadFile(file); err == nil && pattern.Match(data) {
+			return true
+		}
+	}
+	return false
+}
+
+// nextMigrationSequence 计算下一个迁移序号
+func (g *generator) nextMigrationSequence() (int, error) {
+	files, err := filepath.Glob(filepath.Join(g.root, migrationsDir, "*.go"))
+	if err != nil {
+		return 0, err
+	}
+	pattern := regexp.MustCompile(`"` + migrationNamePrefix + `(\d{6})_`)
+	last := 0
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return 0, err
+		}
+		for _, match := range pattern.FindAllSubmatch(data, -1) {
+			if sequence, _ := strconv.Atoi(string(match[1])); sequence > last {
+				last = sequence
+			}
+		}
+	}
+	return last + 1, nil
+}
+
+// registerMigration 把迁移加入 GetMigrationFiles 返回的迁移列表末尾
+func (g *generator) registerMigration(s *scaffold) error {
+	path := filepath.Join(migrationsDir, "Migration.go")
+	return g.patch(path, func(content string) (string, error) {
+		entry := "&" + s.Migration + "{},"
+		if strings.Contains(content, entry) {
+			return content, nil
+		}
+		start := strings.Index(content, "func (m *MigrationManager) GetMigrationFiles()")
+		if start < 0 {
+			return "", fmt.Errorf("%s 中未找到 GetMigrationFiles", path)
+		}
+		end := strings.Index(content[start:], "\n\t}\n}")
+		if end < 0 {
+			return "", fmt.Errorf("%s 中未找到迁移列表", path)
+		}
+		end += start
+		return content[:end] + "\n\t\t" + entry + content[end:], nil
+	})
+}
+
+// registerRoutes 在 routes.go 的生成标记之前注册资源路由
+func (g *generator) registerRoutes(s *scaffold) error {
+	path := filepath.Join(routesDir, "routes.go")
+	return g.patch(path, func(content string) (string, error) {
+		call := fmt.Sprintf("Register%sRoutes(engine, storageManager, Controllers.New%sController(Services.New%sService(db)))", s.Name, s.Name, s.Name)
+		if strings.Contains(content, call) {
+			return content, nil
+		}
+		index := strings.Index(content, routesMarker)
+		if index < 0 {
+			return "", fmt.Errorf("%s 中未找到路由注册标记 %q，请手动注册: %s", path, routesMarker, call)
+		}
+		lineStart := strings.LastIndex(content[:index], "\n") + 1
+		indent := content[lineStart:index]
+		return content[:lineStart] + indent + call + "\n" + content[lineStart:], nil
+	})
+}
+
+// patch 修改已有的源文件，保持原有的换行符
+func (g *generator) patch(path string, edit func(content string) (string, error)) error {
+	fullPath := filepath.Join(g.root, path)
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		return err
+	}
+	content := string(data)
+	crlf := strings.Contains(content, "\r\n")
+	if crlf {
+		content = strings.ReplaceAll(content, "\r\n", "\n")
+	}
+
+	updated, err := edit(content)
+	if err != nil {
+		return err
+	}
+	if updated == content {
+		return nil
+	}
+	if crlf {
+		updated = strings.ReplaceAll(updated, "\n", "\r\n")
+	}
+	if err := os.WriteFile(fullPath, []byte(updated), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(g.app.out, "✅ 已更新 %s\n", path)
+	return nil
+}
+
+// pascalCase 将 article_tag、article-tag、articleTag 等形式转换为 ArticleTag
+func pascalCase(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range strings.TrimSpace(name) {
+		if r == '_' || r == '-' || r == ' ' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// snakeCase 将 ArticleTag 转换为 article_tag，连续大写视为一个词，如 SMSTemplate 转换为 sms_template
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// lowerFirst 首个单词转为小写，如 ArticleTag 转换为 articleTag，SMSTemplate 转换为 smsTemplate
+func lowerFirst(name string) string {
+	runes := []rune(name)
+	for i := range runes {
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		if !unicode.IsUpper(runes[i]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// pluralize 英文名词复数，只处理常见规则
+func pluralize(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return name[:len(name)-1] + "ies"
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return name + "es"
+	default:
+		return name + "s"
+	}
+}
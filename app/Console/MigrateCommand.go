@@ -0,0 +1,230 @@
+package Console
+
+import (
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Database/Migrations"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// migrateCommand 数据库迁移命令，与 scripts/migrate.go 使用同一个迁移管理器
+func (a *Application) migrateCommand() *Command {
+	var (
+		steps            int
+		force            bool
+		planOnly         bool
+		asJSON           bool
+		planFile         string
+		allowDestructive bool
+		hash             string
+		approvedBy       string
+		comment          string
+	)
+	return &Command{
+		Name:  "migrate",
+		Short: "数据库迁移",
+		Subcommands: []*Command{
+			{
+				Name:  "up",
+				Short: "执行待执行的迁移",
+				Usage: "cloudctl migrate up [--plan [--json] [--out 文件]] [--allow-destructive]",
+				Flags: func(fs *flag.FlagSet) {
+					fs.BoolVar(&planOnly, "plan", false, "只生成迁移计划，不执行")
+					fs.BoolVar(&asJSON, "json", false, "以JSON输出迁移计划")
+					fs.StringVar(&planFile, "out", "", "将迁移计划以JSON写入文件，用于审批")
+					fs.BoolVar(&allowDestructive, "allow-destructive", false, "安全模式下允许执行破坏性变更")
+				},
+				Run: func(args []string) error {
+					manager, err := a.migrationManager()
+					if err != nil {
+						return err
+					}
+					options := a.preflightOptions()
+					if planOnly {
+						return a.printMigrationPlan(manager, options, asJSON, planFile)
+					}
+					options.AllowDestructive = allowDestructive
+					if err := manager.RunMigrationsWithPreflight(options); err != nil {
+						return fmt.Errorf("迁移失败: %v", err)
+					}
+					fmt.Fprintln(a.out, "✅ 数据库迁移完成")
+					return nil
+				},
+			},
+			{
+				Name:  "approve",
+				Short: "审批迁移计划，生产环境执行迁移前需要审批",
+				Usage: "cloudctl migrate approve --hash <计划哈希> --by <审批人> [--comment 说明]",
+				Flags: func(fs *flag.FlagSet) {
+					fs.StringVar(&hash, "hash", "", "migrate up --plan 输出的计划哈希")
+					fs.StringVar(&approvedBy, "by", "", "审批人")
+					fs.StringVar(&comment, "comment", "", "审批说明")
+				},
+				Run: func(args []string) error {
+					if hash == "" || approvedBy == "" {
+						return fmt.Errorf("请使用 --hash 和 --by 指定计划哈希和审批人")
+					}
+					manager, err := a.migrationManager()
+					if err != nil {
+						return err
+					}
+					approval, err := manager.ApprovePlan(hash, approvedBy, comment, a.preflightOptions())
+					if err != nil {
+						return fmt.Errorf("审批失败: %v", err)
+					}
+					fmt.Fprintf(a.out, "✅ 迁移计划已审批: %s（审批人: %s，迁移: %s）\n", approval.PlanHash, approval.ApprovedBy, approval.Migrations)
+					return nil
+				},
+			},
+			{
+				Name:  "rollback",
+				Short: "回滚最近的迁移批次",
+				Flags: func(fs *flag.FlagSet) {
+					fs.IntVar(&steps, "steps", 1, "回滚的批次数")
+				},
+				Run: func(args []string) error {
+					if steps <= 0 {
+						return fmt.Errorf("回滚批次数必须大于0")
+					}
+					manager, err := a.migrationManager()
+					if err != nil {
+						return err
+					}
+					if err := manager.RollbackMigrations(steps); err != nil {
+						return fmt.Errorf("回滚失败: %v", err)
+					}
+					fmt.Fprintf(a.out, "✅ 已回滚 %d 个批次的迁移\n", steps)
+					return nil
+				},
+			},
+			{
+				Name:  "reset",
+				Short: "回滚全部迁移（会删除数据）",
+				Flags: func(fs *flag.FlagSet) {
+					fs.BoolVar(&force, "force", false, "确认回滚全部迁移")
+				},
+				Run: func(args []string) error {
+					if !force {
+						return fmt.Errorf("该操作会删除所有迁移创建的表，请使用 --force 确认")
+					}
+					manager, err := a.migrationManager()
+					if err != nil {
+						return err
+					}
+					if err := manager.ResetMigrations(); err != nil {
+						return fmt.Errorf("重置失败: %v", err)
+					}
+					fmt.Fprintln(a.out, "✅ 数据库重置完成")
+					return nil
+				},
+			},
+			{
+				Name:  "status",
+				Short: "查看迁移状态",
+				Run: func(args []string) error {
+					manager, err := a.migrationManager()
+					if err != nil {
+						return err
+					}
+					status, err := manager.GetMigrationStatus()
+					if err != nil {
+						return fmt.Errorf("获取状态失败: %v", err)
+					}
+
+					fmt.Fprintf(a.out, "总迁移数: %v, 已执行: %v, 待执行: %v, 最后批次: %v\n",
+						status["total_migrations"], status["ran_migrations"], status["pending_migrations"], status["last_batch"])
+					migrations, _ := status["migrations"].([]map[string]interface{})
+					for _, migration := range migrations {
+						if migration["status"] == "ran" {
+							fmt.Fprintf(a.out, "  ✅ %v (批次: %v)\n", migration["name"], migration["batch"])
+						} else {
+							fmt.Fprintf(a.out, "  ⏳ %v (待执行)\n", migration["name"])
+						}
+					}
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// migrationManager 创建迁移管理器
+func (a *Application) migrationManager() (*Migrations.MigrationManager, error) {
+	db, err := a.database()
+	if err != nil {
+		return nil, err
+	}
+	return Migrations.NewMigrationManager(db), nil
+}
+
+// preflightOptions 根据配置生成迁移预检选项
+func (a *Application) preflightOptions() Migrations.PreflightOptions {
+	a.config()
+	return Database.MigrationPreflightOptions()
+}
+
+// printMigrationPlan 输出迁移计划，指定文件时同时写入JSON
+func (a *Application) printMigrationPlan(manager *Migrations.MigrationManager, options Migrations.PreflightOptions, asJSON bool, file string) error {
+	plan, err := manager.Plan(options)
+	if err != nil {
+		return fmt.Errorf("生成迁移计划失败: %v", err)
+	}
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	if file != "" {
+		if err := os.WriteFile(file, data, 0644); err != nil {
+			return fmt.Errorf("写入迁移计划失败: %v", err)
+		}
+	}
+	if asJSON {
+		fmt.Fprintln(a.out, string(data))
+		return nil
+	}
+
+	if plan.Empty() {
+		fmt.Fprintln(a.out, "没有待执行的迁移")
+		return nil
+	}
+	fmt.Fprintf(a.out, "迁移计划（%s），哈希: %s\n", plan.Driver, plan.Hash)
+	for _, migration := range plan.Migrations {
+		fmt.Fprintf(a.out, "  ⏳ %s\n", migration.Name)
+		if migration.Error != "" {
+			fmt.Fprintf(a.out, "     ❌ 无法预检: %s\n", migration.Error)
+		}
+		for _, statement := range migration.Statements {
+			var flags []string
+			if statement.Destructive {
+				flags = append(flags, "破坏性")
+			}
+			if statement.HighRisk {
+				flags = append(flags, "高风险")
+			}
+			if statement.EstimatedLock != "" {
+				flags = append(flags, "预计锁表 "+statement.EstimatedLock)
+			}
+			if statement.Online {
+				flags = append(flags, "在线创建")
+			}
+			line := fmt.Sprintf("     [%s] %s", statement.Kind, statement.SQL)
+			if len(flags) > 0 {
+				line += "  ⚠️  " + strings.Join(flags, "，")
+			}
+			fmt.Fprintln(a.out, line)
+		}
+	}
+	for _, warning := range plan.Warnings {
+		fmt.Fprintf(a.out, "⚠️  %s\n", warning)
+	}
+	if options.SafeMode && plan.Destructive {
+		fmt.Fprintln(a.out, "包含破坏性变更，安全模式下需要使用 --allow-destructive 执行")
+	}
+	if options.RequireApproval {
+		fmt.Fprintf(a.out, "执行前需要审批: cloudctl migrate approve --hash %s --by <审批人>\n", plan.Hash)
+	}
+	return nil
+}
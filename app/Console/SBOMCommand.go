@@ -0,0 +1,85 @@
+package Console
+
+import (
+	"cloud-platform-api/app/SBOM"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// sbomCommand 软件物料清单命令
+func (a *Application) sbomCommand() *Command {
+	var (
+		output   string
+		dir      string
+		packages string
+	)
+	return &Command{
+		Name:  "sbom",
+		Short: "依赖清单生成和许可证报告",
+		Subcommands: []*Command{
+			{
+				Name:  "generate",
+				Short: "生成内嵌到二进制的依赖清单（模块、版本和许可证），构建前执行",
+				Flags: func(fs *flag.FlagSet) {
+					fs.StringVar(&output, "output", "app/SBOM/sbom.json", "输出文件")
+					fs.StringVar(&dir, "dir", ".", "主模块内的目录")
+					fs.StringVar(&packages, "packages", "", "统计依赖的包，逗号分隔，默认为主程序和 cmd 下的命令行工具")
+				},
+				Run: func(args []string) error {
+					var patterns []string
+					for _, pattern := range strings.Split(packages, ",") {
+						if pattern = strings.TrimSpace(pattern); pattern != "" {
+							patterns = append(patterns, pattern)
+						}
+					}
+					doc, err := SBOM.Generate(context.Background(), dir, patterns...)
+					if err != nil {
+						return err
+					}
+					data, err := SBOM.Marshal(doc)
+					if err != nil {
+						return err
+					}
+					if err := os.WriteFile(output, data, 0644); err != nil {
+						return err
+					}
+					fmt.Fprintf(a.out, "✅ 已写入 %s：%d 个模块，%d 个无法识别许可证\n", output, len(doc.Components), doc.Licenses[SBOM.UnknownLicense])
+					return nil
+				},
+			},
+			{
+				Name:  "licenses",
+				Short: "按许可证统计当前二进制的依赖模块",
+				Run: func(args []string) error {
+					doc, err := SBOM.Load()
+					if err != nil {
+						return err
+					}
+					licenses := make([]string, 0, len(doc.Licenses))
+					for license := range doc.Licenses {
+						licenses = append(licenses, license)
+					}
+					sort.Slice(licenses, func(i, j int) bool {
+						if doc.Licenses[licenses[i]] != doc.Licenses[licenses[j]] {
+							return doc.Licenses[licenses[i]] > doc.Licenses[licenses[j]]
+						}
+						return licenses[i] < licenses[j]
+					})
+					for _, license := range licenses {
+						fmt.Fprintf(a.out, "%-14s %d\n", license, doc.Licenses[license])
+					}
+					for _, component := range doc.Components {
+						if component.License == SBOM.UnknownLicense {
+							fmt.Fprintf(a.out, "  %s %s: 无法识别许可证\n", component.Path, component.Version)
+						}
+					}
+					return nil
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,142 @@
+package Console
+
+import (
+	"cloud-platform-api/app/Database/Seeders"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// seedOptions 数据填充参数
+type seedOptions struct {
+	environment   string
+	only          string
+	list          bool
+	adminUsername string
+	adminEmail    string
+	adminPassword string
+	demoPassword  string
+	skipMigrate   bool
+}
+
+// adminFlags 注册管理员参数
+func (o *seedOptions) adminFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.adminUsername, "admin-username", "admin", "管理员用户名，已存在时跳过")
+	fs.StringVar(&o.adminEmail, "admin-email", "admin@example.com", "管理员邮箱")
+	fs.StringVar(&o.adminPassword, "admin-password", "", "管理员密码，为空时生成随机密码并要求首次登录后修改")
+	fs.StringVar(&o.demoPassword, "demo-password", "", "演示用户密码，为空时生成随机密码")
+}
+
+// seedCommand 数据填充命令
+func (a *Application) seedCommand() *Command {
+	var options seedOptions
+	return &Command{
+		Name:  "seed",
+		Short: "按环境填充初始数据（管理员、默认角色、示例告警规则，非生产环境另含演示数据）",
+		Usage: "cloudctl seed [--env 环境] [--only 填充器,...] [--list]",
+		Flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&options.environment, "env", "", "填充环境：production、staging、development、testing、demo，默认为 APP_ENV")
+			fs.StringVar(&options.only, "only", "", "只执行指定的填充器，逗号分隔")
+			fs.BoolVar(&options.list, "list", false, "列出环境对应的填充器，不执行")
+			options.adminFlags(fs)
+		},
+		Run: func(args []string) error {
+			environment := options.environment
+			if environment == "" {
+				environment = a.config().Server.Environment
+			}
+			seeders := Seeders.ForEnvironment(environment)
+			if options.only != "" {
+				var err error
+				if seeders, err = Seeders.Select(seeders, strings.Split(options.only, ",")); err != nil {
+					return err
+				}
+			}
+			if options.list {
+				for _, seeder := range seeders {
+					fmt.Fprintln(a.out, seeder.GetName())
+				}
+				return nil
+			}
+			return a.seed(environment, seeders, options)
+		},
+	}
+}
+
+// bootstrapDemoCommand 演示环境初始化命令
+func (a *Application) bootstrapDemoCommand() *Command {
+	var options seedOptions
+	return &Command{
+		Name:  "bootstrap-demo",
+		Short: "执行迁移并填充完整的演示租户，用于新人上手和演示",
+		Flags: func(fs *flag.FlagSet) {
+			fs.BoolVar(&options.skipMigrate, "skip-migrate", false, "跳过数据库迁移")
+			options.adminFlags(fs)
+		},
+		Run: func(args []string) error {
+			if !options.skipMigrate {
+				manager, err := a.migrationManager()
+				if err != nil {
+					return err
+				}
+				if err := manager.RunMigrationsWithPreflight(a.preflightOptions()); err != nil {
+					return fmt.Errorf("迁移失败: %v", err)
+				}
+				fmt.Fprintln(a.out, "✅ 数据库迁移完成")
+			}
+			if err := a.seed(Seeders.EnvironmentDemo, Seeders.ForEnvironment(Seeders.EnvironmentDemo), options); err != nil {
+				return err
+			}
+			fmt.Fprintf(a.out, "演示组织: %s，团队: %s\n", Seeders.DemoOrganizationName, Seeders.DemoTeamName)
+			return nil
+		},
+	}
+}
+
+// seed 执行填充器并输出结果，未指定密码时生成随机密码
+func (a *Application) seed(environment string, seeders []Seeders.Seeder, options seedOptions) error {
+	seederOptions := Seeders.Options{
+		AdminUsername: options.adminUsername,
+		AdminEmail:    options.adminEmail,
+		AdminPassword: options.adminPassword,
+		DemoPassword:  options.demoPassword,
+	}
+	var err error
+	if seederOptions.AdminPassword == "" {
+		if seederOptions.AdminPassword, err = generatePassword(); err != nil {
+			return err
+		}
+		seederOptions.AdminMustChangePassword = true
+	}
+	if seederOptions.DemoPassword == "" {
+		if seederOptions.DemoPassword, err = generatePassword(); err != nil {
+			return err
+		}
+	}
+
+	db, err := a.database()
+	if err != nil {
+		return err
+	}
+	report, err := Seeders.NewSeederManager(db).Run(environment, seeders, seederOptions)
+	for _, result := range report.Seeders {
+		fmt.Fprintf(a.out, "✅ %s\n", result.Name)
+		for _, note := range result.Notes {
+			fmt.Fprintf(a.out, "   %s\n", note)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if len(report.Credentials) > 0 {
+		fmt.Fprintln(a.out, "创建的账号（密码不会再次显示）:")
+		for _, credential := range report.Credentials {
+			fmt.Fprintf(a.out, "  %-6s %-16s %s\n", credential.Role, credential.Username, credential.Password)
+		}
+		if seederOptions.AdminMustChangePassword {
+			fmt.Fprintln(a.out, "管理员首次登录后需要修改密码")
+		}
+	}
+	fmt.Fprintf(a.out, "✅ 数据填充完成（环境: %s）\n", environment)
+	return nil
+}
@@ -0,0 +1,101 @@
+package Console
+
+import (
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Utils"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// generatedPasswordLength 未指定密码时生成的初始密码长度
+const generatedPasswordLength = 16
+
+// userCommand 用户管理命令
+func (a *Application) userCommand() *Command {
+	var username, email, password string
+	return &Command{
+		Name:  "user",
+		Short: "用户管理",
+		Subcommands: []*Command{
+			{
+				Name:  "create-admin",
+				Short: "创建管理员账号",
+				Flags: func(fs *flag.FlagSet) {
+					fs.StringVar(&username, "username", "", "用户名（必填）")
+					fs.StringVar(&email, "email", "", "邮箱（必填）")
+					fs.StringVar(&password, "password", "", "密码，为空时生成随机密码并要求首次登录后修改")
+				},
+				Run: func(args []string) error {
+					return a.createAdmin(strings.TrimSpace(username), strings.TrimSpace(email), password)
+				},
+			},
+		},
+	}
+}
+
+// createAdmin 创建管理员
+// 功能说明：
+// 1. 通过用户服务创建用户，用户名和邮箱唯一性检查、用户创建事件与后台创建用户一致
+// 2. 指定的密码需满足密码强度要求；未指定时生成随机密码，输出一次并要求首次登录后修改
+func (a *Application) createAdmin(username, email, password string) error {
+	if username == "" || email == "" {
+		return fmt.Errorf("用户名和邮箱不能为空")
+	}
+
+	generated := password == ""
+	if generated {
+		var err error
+		if password, err = generatePassword(); err != nil {
+			return err
+		}
+	} else if ok, problems := Utils.ValidatePasswordStrength(password); !ok {
+		return fmt.Errorf("密码强度不足: %s", strings.Join(problems, "; "))
+	}
+
+	hashedPassword, err := Utils.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("密码加密失败: %v", err)
+	}
+
+	db, err := a.database()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	user, err := Services.NewUserServiceWithDB(db).CreateUser(&Models.User{
+		Username:           username,
+		Email:              email,
+		Password:           hashedPassword,
+		Role:               "admin",
+		Status:             1,
+		PasswordChangedAt:  &now,
+		MustChangePassword: generated,
+	})
+	if err != nil {
+		return fmt.Errorf("创建管理员失败: %v", err)
+	}
+
+	fmt.Fprintf(a.out, "✅ 管理员已创建: id=%d, username=%s, email=%s\n", user.ID, user.Username, user.Email)
+	if generated {
+		fmt.Fprintf(a.out, "初始密码: %s\n首次登录后需要修改密码，该密码不会再次显示\n", password)
+	}
+	return nil
+}
+
+// generatePassword 生成满足密码强度要求的随机密码
+func generatePassword() (string, error) {
+	passwordUtils := Utils.NewPasswordUtils()
+	for i := 0; i < 10; i++ {
+		password, err := passwordUtils.GeneratePassword(generatedPasswordLength)
+		if err != nil {
+			return "", fmt.Errorf("生成密码失败: %v", err)
+		}
+		if ok, _ := passwordUtils.ValidatePasswordStrength(password); ok {
+			return password, nil
+		}
+	}
+	return "", fmt.Errorf("生成密码失败，请使用 --password 指定密码")
+}
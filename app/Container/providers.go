@@ -7,7 +7,6 @@ import (
 	"cloud-platform-api/app/Storage"
 	"cloud-platform-api/app/Utils"
 	"fmt"
-	"time"
 
 	"gorm.io/gorm"
 )
@@ -139,16 +138,7 @@ func (p *BusinessServiceProvider) Register(container *Container) error {
 		storageManager, _ := container.Get("storage_manager")
 		config, _ := container.Get("config")
 		storageConfig := config.(*Config.Config).Storage
-		return Services.NewBackupService(storageManager.(*Storage.StorageManager), &Services.BackupConfig{
-			EnableAutoBackup:    storageConfig.EnableAutoBackup,
-			BackupInterval:      time.Duration(storageConfig.BackupInterval) * time.Minute,
-			MaxBackupFiles:      storageConfig.MaxBackupFiles,
-			BackupRetentionDays: storageConfig.BackupRetentionDays,
-			BackupPath:          storageConfig.BackupPath,
-			EnableCompression:   storageConfig.EnableCompression,
-			EnableEncryption:    storageConfig.EnableEncryption,
-			EncryptionKey:       storageConfig.EncryptionKey,
-		})
+		return Services.NewBackupService(storageManager.(*Storage.StorageManager), Services.NewBackupConfigFromStorage(&storageConfig))
 	})
 
 	// 注册WebSocket服务
@@ -0,0 +1,70 @@
+package Dashboard
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// apiPrefixes 转发给API处理器的路径，仪表板页面通过同源请求调用现有的JSON接口
+var apiPrefixes = []string{"/api/", "/health"}
+
+// Server 仪表板请求处理器，由服务器管理器在仪表板端口监听
+// 功能说明：
+// 1. 提供内嵌（go:embed）的单页管理仪表板，展示健康状态、实时指标图表、活动告警和最近的安全事件
+// 2. /api/ 和 /health 开头的请求在进程内转发给API处理器，页面与接口同源，不需要跨域配置
+// 3. 页面登录后使用JWT调用接口，权限检查与API端口相同；API处理器与API端口相同，启用管理端口后管理路由同样不可访问
+// 4. 没有扩展名的未知路径返回 index.html，由前端路由处理
+type Server struct {
+	api    http.Handler
+	static http.Handler
+}
+
+// NewServer 创建仪表板请求处理器，api 为转发接口请求的处理器
+func NewServer(api http.Handler) *Server {
+	root, _ := fs.Sub(staticFiles, "static")
+	return &Server{
+		api:    api,
+		static: http.FileServer(http.FS(root)),
+	}
+}
+
+// ServeHTTP 处理仪表板请求
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	for _, prefix := range apiPrefixes {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			s.api.ServeHTTP(w, r)
+			return
+		}
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; img-src 'self' data:; frame-ancestors 'none'")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	name := strings.TrimPrefix(path.Clean(r.URL.Path), "/")
+	if name == "" || name == "index.html" || (path.Ext(name) == "" && !s.exists(name)) {
+		// 页面每次从服务器获取，使升级后立即加载新版本的脚本
+		w.Header().Set("Cache-Control", "no-cache")
+		http.ServeFileFS(w, r, staticFiles, "static/index.html")
+		return
+	}
+	s.static.ServeHTTP(w, r)
+}
+
+// exists 静态文件是否存在
+func (s *Server) exists(name string) bool {
+	_, err := fs.Stat(staticFiles, "static/"+name)
+	return err == nil
+}
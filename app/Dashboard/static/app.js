@@ -0,0 +1,208 @@
+// 管理仪表板
+// 通过同源的JSON接口获取数据，登录后把JWT保存在 sessionStorage 中，关闭标签页后需要重新登录
+(function () {
+  'use strict';
+
+  var TOKEN_KEY = 'dashboard_token';
+  var USER_KEY = 'dashboard_user';
+  var REFRESH_INTERVAL = 5000;   // 指标和告警的刷新间隔
+  var SLOW_REFRESH_EVERY = 6;    // 健康状态和安全事件每隔几次刷新一次
+  var CHART_POINTS = 60;         // 图表保留的采样点数
+
+  var series = { cpu: [], memory: [], goroutines: [] };
+  var timer = null;
+  var ticks = 0;
+
+  function $(id) { return document.getElementById(id); }
+
+  function token() { return sessionStorage.getItem(TOKEN_KEY); }
+
+  // api 调用接口，401时回到登录页
+  function api(path, options) {
+    options = options || {};
+    var headers = { 'Accept': 'application/json' };
+    if (options.body) { headers['Content-Type'] = 'application/json'; }
+    if (token()) { headers['Authorization'] = 'Bearer ' + token(); }
+    return fetch(path, { method: options.method || 'GET', headers: headers, body: options.body })
+      .then(function (resp) {
+        return resp.json().catch(function () { return {}; }).then(function (body) {
+          if (resp.status === 401 && path.indexOf('/api/v1/auth/') !== 0) {
+            logout();
+          }
+          if (!resp.ok && !options.allowError) {
+            throw new Error(body.message || resp.statusText);
+          }
+          return body;
+        });
+      });
+  }
+
+  function text(value) {
+    return value === undefined || value === null || value === '' ? '-' : String(value);
+  }
+
+  function formatTime(value) {
+    if (!value) { return '-'; }
+    var date = new Date(value);
+    return isNaN(date.getTime()) ? text(value) : date.toLocaleString();
+  }
+
+  function badge(value, prefix) {
+    var span = document.createElement('span');
+    span.className = 'badge ' + (prefix || '') + text(value).toLowerCase();
+    span.textContent = text(value);
+    return span;
+  }
+
+  // fillTable 按行渲染表格，单元格为字符串或元素
+  function fillTable(tbody, rows, columns) {
+    tbody.textContent = '';
+    if (!rows.length) {
+      var empty = tbody.insertRow();
+      var cell = empty.insertCell();
+      cell.colSpan = columns;
+      cell.className = 'empty';
+      cell.textContent = '暂无数据';
+      return;
+    }
+    rows.forEach(function (values) {
+      var row = tbody.insertRow();
+      values.forEach(function (value) {
+        var cell = row.insertCell();
+        if (value instanceof Node) { cell.appendChild(value); } else { cell.textContent = text(value); }
+      });
+    });
+  }
+
+  // drawChart 把采样绘制为折线，纵轴从0到最大值
+  function drawChart(svg, points) {
+    svg.textContent = '';
+    if (points.length < 2) { return; }
+    var max = Math.max.apply(null, points) || 1;
+    var step = 300 / (CHART_POINTS - 1);
+    var offset = CHART_POINTS - points.length;
+    var coords = points.map(function (value, i) {
+      return ((offset + i) * step).toFixed(1) + ',' + (100 - value / max * 95).toFixed(1);
+    });
+    var line = document.createElementNS('http://www.w3.org/2000/svg', 'polyline');
+    line.setAttribute('points', coords.join(' '));
+    svg.appendChild(line);
+  }
+
+  function pushSample(name, value) {
+    var points = series[name];
+    points.push(Number(value) || 0);
+    if (points.length > CHART_POINTS) { points.shift(); }
+    drawChart($(name + '-chart'), points);
+    $(name + '-value').textContent = name === 'goroutines' ? String(value) : (Number(value) || 0).toFixed(1);
+  }
+
+  function loadHealth() {
+    return api('/health/detailed', { allowError: true }).then(function (body) {
+      var data = body.data || {};
+      var status = $('health-status');
+      status.className = 'badge ' + text(data.status);
+      status.textContent = text(data.status);
+      var list = $('health-services');
+      list.textContent = '';
+      Object.keys(data.services || {}).sort().forEach(function (name) {
+        var service = data.services[name];
+        var item = document.createElement('div');
+        var dt = document.createElement('dt');
+        var dd = document.createElement('dd');
+        dt.textContent = name;
+        dd.appendChild(badge(service.status));
+        if (service.message) { dd.title = service.message; }
+        item.appendChild(dt);
+        item.appendChild(dd);
+        list.appendChild(item);
+      });
+    });
+  }
+
+  function loadMetrics() {
+    return api('/api/v1/monitoring/metrics?type=system').then(function (body) {
+      var metrics = (body.data && body.data.metrics) || {};
+      pushSample('cpu', metrics.cpu_usage);
+      pushSample('memory', metrics.memory_usage);
+      pushSample('goroutines', metrics.goroutines);
+      $('metrics-updated').textContent = '更新于 ' + formatTime(metrics.timestamp || new Date());
+    });
+  }
+
+  function loadAlerts() {
+    return api('/api/v1/monitoring/alerts?status=active&limit=20').then(function (body) {
+      fillTable($('alerts'), (body.data || []).map(function (alert) {
+        return [badge(alert.level, 'level-'), alert.message, alert.metric, alert.value, formatTime(alert.created_at)];
+      }), 5);
+    });
+  }
+
+  function loadSecurityEvents() {
+    return api('/api/v1/security/events?limit=10').then(function (body) {
+      fillTable($('security-events'), (body.data || []).map(function (event) {
+        return [badge(event.event_level, 'level-'), event.event_type, event.username, event.ip_address, event.risk_score, formatTime(event.created_at)];
+      }), 6);
+    });
+  }
+
+  function refresh() {
+    var tasks = [loadMetrics(), loadAlerts()];
+    if (ticks % SLOW_REFRESH_EVERY === 0) {
+      tasks.push(loadHealth(), loadSecurityEvents());
+    }
+    ticks++;
+    tasks.forEach(function (task) {
+      task.catch(function (err) { console.warn('仪表板数据加载失败:', err.message); });
+    });
+  }
+
+  function showDashboard() {
+    $('login-view').hidden = true;
+    $('dashboard-view').hidden = false;
+    $('session').hidden = false;
+    $('current-user').textContent = sessionStorage.getItem(USER_KEY) || '';
+    ticks = 0;
+    refresh();
+    timer = setInterval(refresh, REFRESH_INTERVAL);
+  }
+
+  function showLogin() {
+    if (timer) { clearInterval(timer); timer = null; }
+    $('dashboard-view').hidden = true;
+    $('session').hidden = true;
+    $('login-view').hidden = false;
+  }
+
+  function logout() {
+    if (token()) {
+      api('/api/v1/auth/logout', { method: 'POST', allowError: true }).catch(function () {});
+    }
+    sessionStorage.removeItem(TOKEN_KEY);
+    sessionStorage.removeItem(USER_KEY);
+    showLogin();
+  }
+
+  $('login-form').addEventListener('submit', function (event) {
+    event.preventDefault();
+    var form = event.target;
+    $('login-error').textContent = '';
+    api('/api/v1/auth/login', {
+      method: 'POST',
+      body: JSON.stringify({ username: form.username.value, password: form.password.value })
+    }).then(function (body) {
+      var data = body.data || {};
+      if (!data.token) { throw new Error(body.message || '登录失败'); }
+      sessionStorage.setItem(TOKEN_KEY, data.token);
+      sessionStorage.setItem(USER_KEY, (data.user && (data.user.username || data.user.email)) || '');
+      form.password.value = '';
+      showDashboard();
+    }).catch(function (err) {
+      $('login-error').textContent = err.message;
+    });
+  });
+
+  $('logout').addEventListener('click', logout);
+
+  if (token()) { showDashboard(); } else { showLogin(); }
+})();
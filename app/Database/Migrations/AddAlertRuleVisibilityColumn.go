@@ -0,0 +1,30 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// AddAlertRuleVisibilityColumn 为告警规则添加可见范围字段，已有规则为 public，保持所有用户可见
+type AddAlertRuleVisibilityColumn struct{}
+
+// GetName 获取迁移名称
+func (m *AddAlertRuleVisibilityColumn) GetName() string {
+	return "2024_01_01_000043_add_alert_rule_visibility_column"
+}
+
+// Up 执行迁移
+func (m *AddAlertRuleVisibilityColumn) Up(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&Models.AlertRule{}) || db.Migrator().HasColumn(&Models.AlertRule{}, "Visibility") {
+		return nil
+	}
+	return db.Migrator().AddColumn(&Models.AlertRule{}, "Visibility")
+}
+
+// Down 回滚迁移
+func (m *AddAlertRuleVisibilityColumn) Down(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&Models.AlertRule{}) || !db.Migrator().HasColumn(&Models.AlertRule{}, "Visibility") {
+		return nil
+	}
+	return db.Migrator().DropColumn(&Models.AlertRule{}, "Visibility")
+}
@@ -0,0 +1,79 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// AddCorrelationIDColumns 为审计日志、安全事件、领域事件和Webhook投递记录添加请求ID和关联ID字段
+type AddCorrelationIDColumns struct{}
+
+// correlationColumns 需要添加的字段，只处理已存在的表
+// 审计日志和安全事件按关联ID建立索引，用于跨系统查询同一组请求的记录
+func (m *AddCorrelationIDColumns) correlationColumns() []struct {
+	model   interface{}
+	columns []string
+	indexed bool
+} {
+	return []struct {
+		model   interface{}
+		columns []string
+		indexed bool
+	}{
+		{&Models.AuditLog{}, []string{"CorrelationID"}, true},
+		{&Models.SecurityEvent{}, []string{"RequestID", "CorrelationID"}, true},
+		{&Models.OutboxEvent{}, []string{"RequestID", "CorrelationID"}, false},
+		{&Models.WebhookDelivery{}, []string{"RequestID", "CorrelationID"}, false},
+	}
+}
+
+// GetName 获取迁移名称
+func (m *AddCorrelationIDColumns) GetName() string {
+	return "2024_01_01_000041_add_correlation_id_columns"
+}
+
+// Up 执行迁移
+func (m *AddCorrelationIDColumns) Up(db *gorm.DB) error {
+	for _, table := range m.correlationColumns() {
+		if !db.Migrator().HasTable(table.model) {
+			continue
+		}
+		for _, column := range table.columns {
+			if db.Migrator().HasColumn(table.model, column) {
+				continue
+			}
+			if err := db.Migrator().AddColumn(table.model, column); err != nil {
+				return err
+			}
+		}
+		if table.indexed && !db.Migrator().HasIndex(table.model, "CorrelationID") {
+			if err := db.Migrator().CreateIndex(table.model, "CorrelationID"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Down 回滚迁移
+func (m *AddCorrelationIDColumns) Down(db *gorm.DB) error {
+	for _, table := range m.correlationColumns() {
+		if !db.Migrator().HasTable(table.model) {
+			continue
+		}
+		if table.indexed && db.Migrator().HasIndex(table.model, "CorrelationID") {
+			if err := db.Migrator().DropIndex(table.model, "CorrelationID"); err != nil {
+				return err
+			}
+		}
+		for _, column := range table.columns {
+			if !db.Migrator().HasColumn(table.model, column) {
+				continue
+			}
+			if err := db.Migrator().DropColumn(table.model, column); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
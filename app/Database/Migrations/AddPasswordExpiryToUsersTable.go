@@ -0,0 +1,31 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// AddPasswordExpiryToUsersTable 为用户表添加密码过期相关字段
+type AddPasswordExpiryToUsersTable struct{}
+
+// GetName 获取迁移名称
+func (m *AddPasswordExpiryToUsersTable) GetName() string {
+	return "2024_01_01_000009_add_password_expiry_to_users_table"
+}
+
+// Up 执行迁移
+func (m *AddPasswordExpiryToUsersTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.User{})
+}
+
+// Down 回滚迁移
+func (m *AddPasswordExpiryToUsersTable) Down(db *gorm.DB) error {
+	for _, column := range []string{"PasswordChangedAt", "MustChangePassword", "PasswordExpiryNotifiedAt"} {
+		if db.Migrator().HasColumn(&Models.User{}, column) {
+			if err := db.Migrator().DropColumn(&Models.User{}, column); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// AddPhoneToUsersTable 为用户表添加手机号字段
+type AddPhoneToUsersTable struct{}
+
+// GetName 获取迁移名称
+func (m *AddPhoneToUsersTable) GetName() string {
+	return "2024_01_01_000015_add_phone_to_users_table"
+}
+
+// Up 执行迁移
+func (m *AddPhoneToUsersTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.User{})
+}
+
+// Down 回滚迁移
+func (m *AddPhoneToUsersTable) Down(db *gorm.DB) error {
+	if db.Migrator().HasColumn(&Models.User{}, "Phone") {
+		return db.Migrator().DropColumn(&Models.User{}, "Phone")
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// AddSoftDeleteColumns 为告警规则和监控仪表板添加软删除字段，删除后进入回收站
+type AddSoftDeleteColumns struct{}
+
+// softDeleteModels 新增软删除的模型，只处理已存在的表
+func (m *AddSoftDeleteColumns) softDeleteModels() []interface{} {
+	return []interface{}{
+		&Models.AlertRule{},
+		&Models.MonitoringDashboard{},
+	}
+}
+
+// GetName 获取迁移名称
+func (m *AddSoftDeleteColumns) GetName() string {
+	return "2024_01_01_000025_add_soft_delete_columns"
+}
+
+// Up 执行迁移
+func (m *AddSoftDeleteColumns) Up(db *gorm.DB) error {
+	for _, model := range m.softDeleteModels() {
+		if !db.Migrator().HasTable(model) || db.Migrator().HasColumn(model, "DeletedAt") {
+			continue
+		}
+		if err := db.Migrator().AddColumn(model, "DeletedAt"); err != nil {
+			return err
+		}
+		if err := db.Migrator().CreateIndex(model, "DeletedAt"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down 回滚迁移
+func (m *AddSoftDeleteColumns) Down(db *gorm.DB) error {
+	for _, model := range m.softDeleteModels() {
+		if !db.Migrator().HasTable(model) || !db.Migrator().HasColumn(model, "DeletedAt") {
+			continue
+		}
+		if db.Migrator().HasIndex(model, "DeletedAt") {
+			if err := db.Migrator().DropIndex(model, "DeletedAt"); err != nil {
+				return err
+			}
+		}
+		if err := db.Migrator().DropColumn(model, "DeletedAt"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
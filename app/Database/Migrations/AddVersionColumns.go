@@ -0,0 +1,52 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// AddVersionColumns 为支持乐观锁的表添加版本号字段
+type AddVersionColumns struct{}
+
+// versionedModels 带版本号的模型，只处理已存在的表
+func (m *AddVersionColumns) versionedModels() []interface{} {
+	return []interface{}{
+		&Models.User{},
+		&Models.Post{},
+		&Models.Category{},
+		&Models.Tag{},
+		&Models.ApiKey{},
+		&Models.AuditLog{},
+		&Models.AlertRule{},
+		&Models.AccessControl{},
+	}
+}
+
+// GetName 获取迁移名称
+func (m *AddVersionColumns) GetName() string {
+	return "2024_01_01_000024_add_version_columns"
+}
+
+// Up 执行迁移，已有记录的版本号为1
+func (m *AddVersionColumns) Up(db *gorm.DB) error {
+	for _, model := range m.versionedModels() {
+		if db.Migrator().HasTable(model) && !db.Migrator().HasColumn(model, "Version") {
+			if err := db.Migrator().AddColumn(model, "Version"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Down 回滚迁移
+func (m *AddVersionColumns) Down(db *gorm.DB) error {
+	for _, model := range m.versionedModels() {
+		if db.Migrator().HasTable(model) && db.Migrator().HasColumn(model, "Version") {
+			if err := db.Migrator().DropColumn(model, "Version"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateAdminApprovalsTable 创建敏感操作审批表
+type CreateAdminApprovalsTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateAdminApprovalsTable) GetName() string {
+	return "2024_01_01_000044_create_admin_approvals_table"
+}
+
+// Up 执行迁移
+func (m *CreateAdminApprovalsTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.AdminApproval{})
+}
+
+// Down 回滚迁移
+func (m *CreateAdminApprovalsTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.AdminApproval{})
+}
@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateBackupSchedulesTables 创建定时备份和备份运行记录表
+type CreateBackupSchedulesTables struct{}
+
+// GetName 获取迁移名称
+func (m *CreateBackupSchedulesTables) GetName() string {
+	return "2024_01_01_000035_create_backup_schedules_tables"
+}
+
+// Up 执行迁移
+func (m *CreateBackupSchedulesTables) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.BackupSchedule{}, &Models.BackupRun{})
+}
+
+// Down 回滚迁移
+func (m *CreateBackupSchedulesTables) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.BackupRun{}, &Models.BackupSchedule{})
+}
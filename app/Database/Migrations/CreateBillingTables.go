@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateBillingTables 创建套餐、计费账户和 Stripe 事件表
+type CreateBillingTables struct{}
+
+// GetName 获取迁移名称
+func (m *CreateBillingTables) GetName() string {
+	return "2024_01_01_000031_create_billing_tables"
+}
+
+// Up 执行迁移
+func (m *CreateBillingTables) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.BillingPlan{}, &Models.BillingAccount{}, &Models.BillingEvent{})
+}
+
+// Down 回滚迁移
+func (m *CreateBillingTables) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.BillingEvent{}, &Models.BillingAccount{}, &Models.BillingPlan{})
+}
@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateBulkJobsTable 创建批量任务表和任务错误明细表迁移
+type CreateBulkJobsTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateBulkJobsTable) GetName() string {
+	return "2024_01_01_000021_create_bulk_jobs_table"
+}
+
+// Up 执行迁移
+func (m *CreateBulkJobsTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.BulkJob{}, &Models.BulkJobError{})
+}
+
+// Down 回滚迁移
+func (m *CreateBulkJobsTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.BulkJobError{}, &Models.BulkJob{})
+}
@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateCommentsTable 创建评论表
+type CreateCommentsTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateCommentsTable) GetName() string {
+	return "2024_01_01_000033_create_comments_table"
+}
+
+// Up 执行迁移
+func (m *CreateCommentsTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.Comment{})
+}
+
+// Down 回滚迁移
+func (m *CreateCommentsTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.Comment{})
+}
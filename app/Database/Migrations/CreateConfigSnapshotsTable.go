@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateConfigSnapshotsTable 创建配置快照表
+type CreateConfigSnapshotsTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateConfigSnapshotsTable) GetName() string {
+	return "2024_01_01_000036_create_config_snapshots_table"
+}
+
+// Up 执行迁移
+func (m *CreateConfigSnapshotsTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.ConfigSnapshot{})
+}
+
+// Down 回滚迁移
+func (m *CreateConfigSnapshotsTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.ConfigSnapshot{})
+}
@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateDependencyVulnerabilitiesTable 创建依赖漏洞跟踪表
+type CreateDependencyVulnerabilitiesTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateDependencyVulnerabilitiesTable) GetName() string {
+	return "2024_01_01_000040_create_dependency_vulnerabilities_table"
+}
+
+// Up 执行迁移
+func (m *CreateDependencyVulnerabilitiesTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.DependencyVulnerability{})
+}
+
+// Down 回滚迁移
+func (m *CreateDependencyVulnerabilitiesTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.DependencyVulnerability{})
+}
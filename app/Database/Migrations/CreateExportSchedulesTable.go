@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateExportSchedulesTable 创建定时导出表
+type CreateExportSchedulesTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateExportSchedulesTable) GetName() string {
+	return "2024_01_01_000034_create_export_schedules_table"
+}
+
+// Up 执行迁移
+func (m *CreateExportSchedulesTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.ExportSchedule{})
+}
+
+// Down 回滚迁移
+func (m *CreateExportSchedulesTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.ExportSchedule{})
+}
@@ -0,0 +1,45 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateImpersonationSessionsTable 创建模拟登录会话表，并为审计日志添加模拟管理员字段
+type CreateImpersonationSessionsTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateImpersonationSessionsTable) GetName() string {
+	return "2024_01_01_000026_create_impersonation_sessions_table"
+}
+
+// Up 执行迁移
+func (m *CreateImpersonationSessionsTable) Up(db *gorm.DB) error {
+	if err := db.AutoMigrate(&Models.ImpersonationSession{}); err != nil {
+		return err
+	}
+	auditLog := &Models.AuditLog{}
+	if !db.Migrator().HasTable(auditLog) || db.Migrator().HasColumn(auditLog, "ImpersonatorID") {
+		return nil
+	}
+	if err := db.Migrator().AddColumn(auditLog, "ImpersonatorID"); err != nil {
+		return err
+	}
+	return db.Migrator().CreateIndex(auditLog, "ImpersonatorID")
+}
+
+// Down 回滚迁移
+func (m *CreateImpersonationSessionsTable) Down(db *gorm.DB) error {
+	auditLog := &Models.AuditLog{}
+	if db.Migrator().HasTable(auditLog) && db.Migrator().HasColumn(auditLog, "ImpersonatorID") {
+		if db.Migrator().HasIndex(auditLog, "ImpersonatorID") {
+			if err := db.Migrator().DropIndex(auditLog, "ImpersonatorID"); err != nil {
+				return err
+			}
+		}
+		if err := db.Migrator().DropColumn(auditLog, "ImpersonatorID"); err != nil {
+			return err
+		}
+	}
+	return db.Migrator().DropTable(&Models.ImpersonationSession{})
+}
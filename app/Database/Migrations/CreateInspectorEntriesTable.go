@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateInspectorEntriesTable 创建请求检查器记录表
+type CreateInspectorEntriesTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateInspectorEntriesTable) GetName() string {
+	return "2024_01_01_000045_create_inspector_entries_table"
+}
+
+// Up 执行迁移
+func (m *CreateInspectorEntriesTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.InspectorEntry{})
+}
+
+// Down 回滚迁移
+func (m *CreateInspectorEntriesTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.InspectorEntry{})
+}
@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateLoginAttemptsTable 创建登录尝试记录表迁移
+type CreateLoginAttemptsTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateLoginAttemptsTable) GetName() string {
+	return "2024_01_01_000027_create_login_attempts_table"
+}
+
+// Up 执行迁移
+func (m *CreateLoginAttemptsTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.LoginAttempt{})
+}
+
+// Down 回滚迁移
+func (m *CreateLoginAttemptsTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.LoginAttempt{})
+}
@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateMailMessagesTable 创建邮件投递记录表迁移
+type CreateMailMessagesTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateMailMessagesTable) GetName() string {
+	return "2024_01_01_000010_create_mail_messages_table"
+}
+
+// Up 执行迁移
+func (m *CreateMailMessagesTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.MailMessage{})
+}
+
+// Down 回滚迁移
+func (m *CreateMailMessagesTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.MailMessage{})
+}
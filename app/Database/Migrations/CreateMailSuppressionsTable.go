@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateMailSuppressionsTable 创建邮件屏蔽列表表迁移
+type CreateMailSuppressionsTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateMailSuppressionsTable) GetName() string {
+	return "2024_01_01_000011_create_mail_suppressions_table"
+}
+
+// Up 执行迁移
+func (m *CreateMailSuppressionsTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.MailSuppression{})
+}
+
+// Down 回滚迁移
+func (m *CreateMailSuppressionsTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.MailSuppression{})
+}
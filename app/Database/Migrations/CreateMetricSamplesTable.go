@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateMetricSamplesTable 创建指标历史采样表迁移
+type CreateMetricSamplesTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateMetricSamplesTable) GetName() string {
+	return "2024_01_01_000016_create_metric_samples_table"
+}
+
+// Up 执行迁移
+func (m *CreateMetricSamplesTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.MetricSample{})
+}
+
+// Down 回滚迁移
+func (m *CreateMetricSamplesTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.MetricSample{})
+}
@@ -0,0 +1,36 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateMonitoringCoreTables 创建监控指标、告警规则、告警、监控事件和安全事件表迁移
+// 这些表此前由部署环境单独创建，已存在时只补充缺少的列和索引
+type CreateMonitoringCoreTables struct{}
+
+// monitoringCoreModels 监控核心表模型
+func (m *CreateMonitoringCoreTables) monitoringCoreModels() []interface{} {
+	return []interface{}{
+		&Models.MonitoringMetric{},
+		&Models.AlertRule{},
+		&Models.Alert{},
+		&Models.MonitoringEvent{},
+		&Models.SecurityEvent{},
+	}
+}
+
+// GetName 获取迁移名称
+func (m *CreateMonitoringCoreTables) GetName() string {
+	return "2024_01_01_000042_create_monitoring_core_tables"
+}
+
+// Up 执行迁移
+func (m *CreateMonitoringCoreTables) Up(db *gorm.DB) error {
+	return db.AutoMigrate(m.monitoringCoreModels()...)
+}
+
+// Down 回滚迁移
+func (m *CreateMonitoringCoreTables) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(m.monitoringCoreModels()...)
+}
@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateMonitoringDashboardsTable 创建监控仪表板和组件表迁移
+type CreateMonitoringDashboardsTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateMonitoringDashboardsTable) GetName() string {
+	return "2024_01_01_000017_create_monitoring_dashboards_table"
+}
+
+// Up 执行迁移
+func (m *CreateMonitoringDashboardsTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.MonitoringDashboard{}, &Models.MonitoringWidget{})
+}
+
+// Down 回滚迁移
+func (m *CreateMonitoringDashboardsTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.MonitoringWidget{}, &Models.MonitoringDashboard{})
+}
@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateMonitoringIncidentsTables 创建事件和事件时间线表
+type CreateMonitoringIncidentsTables struct{}
+
+// GetName 获取迁移名称
+func (m *CreateMonitoringIncidentsTables) GetName() string {
+	return "2024_01_01_000032_create_monitoring_incidents_tables"
+}
+
+// Up 执行迁移
+func (m *CreateMonitoringIncidentsTables) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.MonitoringIncident{}, &Models.MonitoringIncidentEvent{})
+}
+
+// Down 回滚迁移
+func (m *CreateMonitoringIncidentsTables) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.MonitoringIncidentEvent{}, &Models.MonitoringIncident{})
+}
@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateMonitoringReportsTable 创建监控调度、报告和报告文件表迁移
+type CreateMonitoringReportsTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateMonitoringReportsTable) GetName() string {
+	return "2024_01_01_000018_create_monitoring_reports_table"
+}
+
+// Up 执行迁移
+func (m *CreateMonitoringReportsTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.MonitoringSchedule{}, &Models.MonitoringReport{}, &Models.MonitoringReportFile{})
+}
+
+// Down 回滚迁移
+func (m *CreateMonitoringReportsTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.MonitoringReportFile{}, &Models.MonitoringReport{}, &Models.MonitoringSchedule{})
+}
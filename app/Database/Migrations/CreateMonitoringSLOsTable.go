@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateMonitoringSLOsTable 创建服务等级目标表迁移
+type CreateMonitoringSLOsTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateMonitoringSLOsTable) GetName() string {
+	return "2024_01_01_000019_create_monitoring_slos_table"
+}
+
+// Up 执行迁移
+func (m *CreateMonitoringSLOsTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.MonitoringSLO{})
+}
+
+// Down 回滚迁移
+func (m *CreateMonitoringSLOsTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.MonitoringSLO{})
+}
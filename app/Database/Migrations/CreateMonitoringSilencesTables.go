@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateMonitoringSilencesTables 创建告警静默和维护窗口表
+type CreateMonitoringSilencesTables struct{}
+
+// GetName 获取迁移名称
+func (m *CreateMonitoringSilencesTables) GetName() string {
+	return "2024_01_01_000046_create_monitoring_silences_tables"
+}
+
+// Up 执行迁移
+func (m *CreateMonitoringSilencesTables) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.MonitoringMaintenanceWindow{}, &Models.MonitoringSilence{})
+}
+
+// Down 回滚迁移
+func (m *CreateMonitoringSilencesTables) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.MonitoringSilence{}, &Models.MonitoringMaintenanceWindow{})
+}
@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateNotificationsTable 创建站内通知表迁移
+type CreateNotificationsTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateNotificationsTable) GetName() string {
+	return "2024_01_01_000012_create_notifications_table"
+}
+
+// Up 执行迁移
+func (m *CreateNotificationsTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.Notification{})
+}
+
+// Down 回滚迁移
+func (m *CreateNotificationsTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.Notification{})
+}
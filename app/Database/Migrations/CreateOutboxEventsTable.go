@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateOutboxEventsTable 创建领域事件发件箱表迁移
+type CreateOutboxEventsTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateOutboxEventsTable) GetName() string {
+	return "2024_01_01_000022_create_outbox_events_table"
+}
+
+// Up 执行迁移
+func (m *CreateOutboxEventsTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.OutboxEvent{})
+}
+
+// Down 回滚迁移
+func (m *CreateOutboxEventsTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.OutboxEvent{})
+}
@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateSMSMessagesTable 创建短信投递记录表迁移
+type CreateSMSMessagesTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateSMSMessagesTable) GetName() string {
+	return "2024_01_01_000014_create_sms_messages_table"
+}
+
+// Up 执行迁移
+func (m *CreateSMSMessagesTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.SMSMessage{})
+}
+
+// Down 回滚迁移
+func (m *CreateSMSMessagesTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.SMSMessage{})
+}
@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateSMSTemplatesTable 创建短信模板表迁移
+type CreateSMSTemplatesTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateSMSTemplatesTable) GetName() string {
+	return "2024_01_01_000013_create_sms_templates_table"
+}
+
+// Up 执行迁移
+func (m *CreateSMSTemplatesTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.SMSTemplate{})
+}
+
+// Down 回滚迁移
+func (m *CreateSMSTemplatesTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.SMSTemplate{})
+}
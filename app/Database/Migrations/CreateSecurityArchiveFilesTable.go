@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateSecurityArchiveFilesTable 创建安全数据归档文件索引表迁移
+type CreateSecurityArchiveFilesTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateSecurityArchiveFilesTable) GetName() string {
+	return "2024_01_01_000047_create_security_archive_files_table"
+}
+
+// Up 执行迁移
+func (m *CreateSecurityArchiveFilesTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.SecurityArchiveFile{})
+}
+
+// Down 回滚迁移
+func (m *CreateSecurityArchiveFilesTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.SecurityArchiveFile{})
+}
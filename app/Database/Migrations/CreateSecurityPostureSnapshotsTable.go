@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateSecurityPostureSnapshotsTable 创建安全态势评分快照表
+type CreateSecurityPostureSnapshotsTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateSecurityPostureSnapshotsTable) GetName() string {
+	return "2024_01_01_000038_create_security_posture_snapshots_table"
+}
+
+// Up 执行迁移
+func (m *CreateSecurityPostureSnapshotsTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.SecurityPostureSnapshot{})
+}
+
+// Down 回滚迁移
+func (m *CreateSecurityPostureSnapshotsTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.SecurityPostureSnapshot{})
+}
@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateSecurityResponseExecutionsTable 创建安全事件响应记录表迁移
+type CreateSecurityResponseExecutionsTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateSecurityResponseExecutionsTable) GetName() string {
+	return "2024_01_01_000008_create_security_response_executions_table"
+}
+
+// Up 执行迁移
+func (m *CreateSecurityResponseExecutionsTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.SecurityResponseExecution{})
+}
+
+// Down 回滚迁移
+func (m *CreateSecurityResponseExecutionsTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.SecurityResponseExecution{})
+}
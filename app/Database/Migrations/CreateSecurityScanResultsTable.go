@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateSecurityScanResultsTable 创建安全扫描结果表
+type CreateSecurityScanResultsTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateSecurityScanResultsTable) GetName() string {
+	return "2024_01_01_000039_create_security_scan_results_table"
+}
+
+// Up 执行迁移
+func (m *CreateSecurityScanResultsTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.SecurityScanResult{})
+}
+
+// Down 回滚迁移
+func (m *CreateSecurityScanResultsTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.SecurityScanResult{})
+}
@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateSigningClientsTables 创建请求签名客户端表和随机数表
+type CreateSigningClientsTables struct{}
+
+// GetName 获取迁移名称
+func (m *CreateSigningClientsTables) GetName() string {
+	return "2024_01_01_000037_create_signing_clients_tables"
+}
+
+// Up 执行迁移
+func (m *CreateSigningClientsTables) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.SigningClient{}, &Models.SigningNonce{})
+}
+
+// Down 回滚迁移
+func (m *CreateSigningClientsTables) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.SigningNonce{}, &Models.SigningClient{})
+}
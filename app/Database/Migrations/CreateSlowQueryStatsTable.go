@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateSlowQueryStatsTable 创建慢查询统计表迁移
+type CreateSlowQueryStatsTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateSlowQueryStatsTable) GetName() string {
+	return "2024_01_01_000006_create_slow_query_stats_table"
+}
+
+// Up 执行迁移
+func (m *CreateSlowQueryStatsTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.SlowQueryStat{})
+}
+
+// Down 回滚迁移
+func (m *CreateSlowQueryStatsTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.SlowQueryStat{})
+}
@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateTablePartitionsTable 创建大表分区记录表迁移
+type CreateTablePartitionsTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateTablePartitionsTable) GetName() string {
+	return "2024_01_01_000020_create_table_partitions_table"
+}
+
+// Up 执行迁移
+func (m *CreateTablePartitionsTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.TablePartition{})
+}
+
+// Down 回滚迁移
+func (m *CreateTablePartitionsTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.TablePartition{})
+}
@@ -0,0 +1,60 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateTeamsTables 创建组织、团队、团队成员和团队邀请表，并为仪表板、告警规则和API密钥添加所属团队字段
+type CreateTeamsTables struct{}
+
+// teamOwnedModels 可以归属团队的模型，只处理已存在的表
+func (m *CreateTeamsTables) teamOwnedModels() []interface{} {
+	return []interface{}{
+		&Models.MonitoringDashboard{},
+		&Models.AlertRule{},
+		&Models.ApiKey{},
+	}
+}
+
+// GetName 获取迁移名称
+func (m *CreateTeamsTables) GetName() string {
+	return "2024_01_01_000029_create_teams_tables"
+}
+
+// Up 执行迁移
+func (m *CreateTeamsTables) Up(db *gorm.DB) error {
+	if err := db.AutoMigrate(&Models.Organization{}, &Models.Team{}, &Models.TeamMember{}, &Models.TeamInvitation{}); err != nil {
+		return err
+	}
+	for _, model := range m.teamOwnedModels() {
+		if !db.Migrator().HasTable(model) || db.Migrator().HasColumn(model, "TeamID") {
+			continue
+		}
+		if err := db.Migrator().AddColumn(model, "TeamID"); err != nil {
+			return err
+		}
+		if err := db.Migrator().CreateIndex(model, "TeamID"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down 回滚迁移
+func (m *CreateTeamsTables) Down(db *gorm.DB) error {
+	for _, model := range m.teamOwnedModels() {
+		if !db.Migrator().HasTable(model) || !db.Migrator().HasColumn(model, "TeamID") {
+			continue
+		}
+		if db.Migrator().HasIndex(model, "TeamID") {
+			if err := db.Migrator().DropIndex(model, "TeamID"); err != nil {
+				return err
+			}
+		}
+		if err := db.Migrator().DropColumn(model, "TeamID"); err != nil {
+			return err
+		}
+	}
+	return db.Migrator().DropTable(&Models.TeamInvitation{}, &Models.TeamMember{}, &Models.Team{}, &Models.Organization{})
+}
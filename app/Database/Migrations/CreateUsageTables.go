@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateUsageTables 创建用量记录和用量限额表
+type CreateUsageTables struct{}
+
+// GetName 获取迁移名称
+func (m *CreateUsageTables) GetName() string {
+	return "2024_01_01_000030_create_usage_tables"
+}
+
+// Up 执行迁移
+func (m *CreateUsageTables) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.UsageRecord{}, &Models.UsageQuota{})
+}
+
+// Down 回滚迁移
+func (m *CreateUsageTables) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.UsageQuota{}, &Models.UsageRecord{})
+}
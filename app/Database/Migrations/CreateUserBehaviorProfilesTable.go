@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateUserBehaviorProfilesTable 创建用户行为画像表迁移
+type CreateUserBehaviorProfilesTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateUserBehaviorProfilesTable) GetName() string {
+	return "2024_01_01_000007_create_user_behavior_profiles_table"
+}
+
+// Up 执行迁移
+func (m *CreateUserBehaviorProfilesTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.UserBehaviorProfile{})
+}
+
+// Down 回滚迁移
+func (m *CreateUserBehaviorProfilesTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.UserBehaviorProfile{})
+}
@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateUserSettingsTable 创建用户偏好设置表迁移
+type CreateUserSettingsTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateUserSettingsTable) GetName() string {
+	return "2024_01_01_000028_create_user_settings_table"
+}
+
+// Up 执行迁移
+func (m *CreateUserSettingsTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.UserSetting{})
+}
+
+// Down 回滚迁移
+func (m *CreateUserSettingsTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.UserSetting{})
+}
@@ -0,0 +1,24 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Models"
+	"gorm.io/gorm"
+)
+
+// CreateWebhookSubscriptionsTable 创建Webhook订阅和投递记录表迁移
+type CreateWebhookSubscriptionsTable struct{}
+
+// GetName 获取迁移名称
+func (m *CreateWebhookSubscriptionsTable) GetName() string {
+	return "2024_01_01_000023_create_webhook_subscriptions_table"
+}
+
+// Up 执行迁移
+func (m *CreateWebhookSubscriptionsTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Models.WebhookSubscription{}, &Models.WebhookDelivery{})
+}
+
+// Down 回滚迁移
+func (m *CreateWebhookSubscriptionsTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Models.WebhookDelivery{}, &Models.WebhookSubscription{})
+}
@@ -25,7 +25,8 @@ type MigrationInterface interface {
 
 // MigrationManager 迁移管理器
 type MigrationManager struct {
-	db *gorm.DB
+	db         *gorm.DB
+	migrations []MigrationInterface
 }
 
 // NewMigrationManager 创建迁移管理器
@@ -75,14 +76,35 @@ func (m *MigrationManager) RunMigrations() error {
 	if err := m.CreateMigrationsTable(); err != nil {
 		return fmt.Errorf("创建迁移表失败: %v", err)
 	}
+	return m.runMigrations(m.db)
+}
 
-	// 获取所有迁移文件
-	migrations := m.GetMigrationFiles()
-
+// pendingMigrations 获取未执行的迁移
+func (m *MigrationManager) pendingMigrations() ([]MigrationInterface, error) {
 	// 获取已运行的迁移
 	ranMigrations, err := m.GetMigrations()
 	if err != nil {
-		return fmt.Errorf("获取已运行迁移失败: %v", err)
+		return nil, fmt.Errorf("获取已运行迁移失败: %v", err)
+	}
+	ranMap := make(map[string]bool)
+	for _, ran := range ranMigrations {
+		ranMap[ran.Migration] = true
+	}
+
+	var pending []MigrationInterface
+	for _, migration := range m.GetMigrationFiles() {
+		if !ranMap[migration.GetName()] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// runMigrations 使用 exec 执行未执行的迁移，迁移记录始终写入 m.db
+func (m *MigrationManager) runMigrations(exec *gorm.DB) error {
+	migrations, err := m.pendingMigrations()
+	if err != nil {
+		return err
 	}
 
 	// 获取最后批次号
@@ -92,33 +114,27 @@ func (m *MigrationManager) RunMigrations() error {
 	}
 
 	currentBatch := lastBatch + 1
-	ranMap := make(map[string]bool)
-	for _, ran := range ranMigrations {
-		ranMap[ran.Migration] = true
-	}
 
 	// 运行未执行的迁移
 	for _, migration := range migrations {
-		if !ranMap[migration.GetName()] {
-			log.Printf("运行迁移: %s", migration.GetName())
-
-			if err := migration.Up(m.db); err != nil {
-				return fmt.Errorf("迁移 %s 执行失败: %v", migration.GetName(), err)
-			}
+		log.Printf("运行迁移: %s", migration.GetName())
 
-			// 记录迁移
-			migrationRecord := Migration{
-				Migration: migration.GetName(),
-				Batch:     currentBatch,
-				CreatedAt: time.Now(),
-			}
+		if err := migration.Up(exec); err != nil {
+			return fmt.Errorf("迁移 %s 执行失败: %v", migration.GetName(), err)
+		}
 
-			if err := m.db.Create(&migrationRecord).Error; err != nil {
-				return fmt.Errorf("记录迁移失败: %v", err)
-			}
+		// 记录迁移
+		migrationRecord := Migration{
+			Migration: migration.GetName(),
+			Batch:     currentBatch,
+			CreatedAt: time.Now(),
+		}
 
-			log.Printf("迁移 %s 执行成功", migration.GetName())
+		if err := m.db.Create(&migrationRecord).Error; err != nil {
+			return fmt.Errorf("记录迁移失败: %v", err)
 		}
+
+		log.Printf("迁移 %s 执行成功", migration.GetName())
 	}
 
 	log.Printf("所有迁移执行完成，当前批次: %d", currentBatch)
@@ -191,14 +207,64 @@ func (m *MigrationManager) ResetMigrations() error {
 	return m.RollbackMigrations(999) // 回滚所有批次
 }
 
+// SetMigrations 设置迁移列表，替换内置的迁移文件，用于测试
+func (m *MigrationManager) SetMigrations(migrations []MigrationInterface) {
+	m.migrations = migrations
+}
+
 // GetMigrationFiles 获取所有迁移文件
 func (m *MigrationManager) GetMigrationFiles() []MigrationInterface {
+	if m.migrations != nil {
+		return m.migrations
+	}
 	return []MigrationInterface{
 		&CreateUsersTable{},
 		&CreatePostsTable{},
 		&CreateCategoriesTable{},
 		&CreateTagsTable{},
 		&CreateAuditLogsTable{},
+		&CreateSlowQueryStatsTable{},
+		&CreateUserBehaviorProfilesTable{},
+		&CreateSecurityResponseExecutionsTable{},
+		&AddPasswordExpiryToUsersTable{},
+		&CreateMailMessagesTable{},
+		&CreateMailSuppressionsTable{},
+		&CreateNotificationsTable{},
+		&CreateSMSTemplatesTable{},
+		&CreateSMSMessagesTable{},
+		&AddPhoneToUsersTable{},
+		&CreateMetricSamplesTable{},
+		&CreateMonitoringDashboardsTable{},
+		&CreateMonitoringReportsTable{},
+		&CreateMonitoringSLOsTable{},
+		&CreateTablePartitionsTable{},
+		&CreateBulkJobsTable{},
+		&CreateOutboxEventsTable{},
+		&CreateWebhookSubscriptionsTable{},
+		&AddVersionColumns{},
+		&AddSoftDeleteColumns{},
+		&CreateImpersonationSessionsTable{},
+		&CreateLoginAttemptsTable{},
+		&CreateUserSettingsTable{},
+		&CreateTeamsTables{},
+		&CreateUsageTables{},
+		&CreateBillingTables{},
+		&CreateMonitoringIncidentsTables{},
+		&CreateCommentsTable{},
+		&CreateExportSchedulesTable{},
+		&CreateBackupSchedulesTables{},
+		&CreateConfigSnapshotsTable{},
+		&CreateSigningClientsTables{},
+		&CreateSecurityPostureSnapshotsTable{},
+		&CreateSecurityScanResultsTable{},
+		&CreateDependencyVulnerabilitiesTable{},
+		&AddCorrelationIDColumns{},
+		&CreateMonitoringCoreTables{},
+		&AddAlertRuleVisibilityColumn{},
+		&CreateAdminApprovalsTable{},
+		&CreateInspectorEntriesTable{},
+		&CreateMonitoringSilencesTables{},
+		&CreateSecurityArchiveFilesTable{},
 	}
 }
 
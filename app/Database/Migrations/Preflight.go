@@ -0,0 +1,591 @@
+package Migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// 语句类型
+const (
+	StatementCreateTable    = "create_table"
+	StatementDropTable      = "drop_table"
+	StatementAddColumn      = "add_column"
+	StatementDropColumn     = "drop_column"
+	StatementAlterColumn    = "alter_column"
+	StatementRename         = "rename"
+	StatementCreateIndex    = "create_index"
+	StatementDropIndex      = "drop_index"
+	StatementAddConstraint  = "add_constraint"
+	StatementDropConstraint = "drop_constraint"
+	StatementData           = "data"
+	StatementOther          = "other"
+)
+
+// PreflightOptions 迁移预检选项
+type PreflightOptions struct {
+	LargeTableRows   int64         // 大表行数阈值
+	RowsPerSecond    int64         // 估算锁表时间时每秒处理的行数
+	MaxLockTime      time.Duration // 允许的最长锁表时间
+	OnlineIndexes    bool          // 是否改写为在线创建索引
+	SafeMode         bool          // 存在破坏性变更时拒绝执行
+	AllowDestructive bool          // 安全模式下显式允许破坏性变更
+	RequireApproval  bool          // 执行前需要审批迁移计划
+	ApprovalTTL      time.Duration // 审批有效期
+}
+
+// PlannedStatement 迁移计划中的语句
+type PlannedStatement struct {
+	SQL           string   `json:"sql"`
+	Kind          string   `json:"kind"`
+	Table         string   `json:"table,omitempty"`
+	Destructive   bool     `json:"destructive"`
+	Rows          int64    `json:"rows,omitempty"`           // 表的估算行数
+	EstimatedLock string   `json:"estimated_lock,omitempty"` // 估算的锁表时间
+	HighRisk      bool     `json:"high_risk"`
+	Online        bool     `json:"online"` // 执行时改写为在线创建索引
+	Notes         []string `json:"notes,omitempty"`
+}
+
+// PlannedMigration 迁移计划中的迁移
+type PlannedMigration struct {
+	Name       string             `json:"name"`
+	Statements []PlannedStatement `json:"statements"`
+	Error      string             `json:"error,omitempty"` // 无法预检的原因
+}
+
+// MigrationPlan 迁移计划
+type MigrationPlan struct {
+	Driver      string             `json:"driver"`
+	Hash        string             `json:"hash"`
+	GeneratedAt time.Time          `json:"generated_at"`
+	Migrations  []PlannedMigration `json:"migrations"`
+	Destructive bool               `json:"destructive"`
+	HighRisk    bool               `json:"high_risk"`
+	Warnings    []string           `json:"warnings,omitempty"`
+}
+
+// Empty 检查是否没有待执行的迁移
+func (p *MigrationPlan) Empty() bool {
+	return len(p.Migrations) == 0
+}
+
+// DestructiveStatements 获取破坏性语句，格式为 "迁移名: SQL"
+func (p *MigrationPlan) DestructiveStatements() []string {
+	var statements []string
+	for _, migration := range p.Migrations {
+		for _, statement := range migration.Statements {
+			if statement.Destructive {
+				statements = append(statements, migration.Name+": "+statement.SQL)
+			}
+		}
+	}
+	return statements
+}
+
+// MigrationPlanApproval 迁移计划审批记录
+type MigrationPlanApproval struct {
+	ID          uint       `json:"id" gorm:"primarykey"`
+	PlanHash    string     `json:"plan_hash" gorm:"size:64;not null;index"`
+	Migrations  string     `json:"migrations" gorm:"type:text"` // 审批时待执行的迁移，逗号分隔
+	Destructive bool       `json:"destructive"`
+	ApprovedBy  string     `json:"approved_by" gorm:"size:100;not null"`
+	Comment     string     `json:"comment" gorm:"size:500"`
+	ApprovedAt  time.Time  `json:"approved_at"`
+	UsedAt      *time.Time `json:"used_at"` // 执行迁移后标记，审批只能使用一次
+}
+
+// TableName 指定表名
+func (MigrationPlanApproval) TableName() string {
+	return "migration_plan_approvals"
+}
+
+var (
+	identifierPattern  = "[`\"\\[]?([A-Za-z0-9_.$]+)[`\"\\]]?"
+	createTablePattern = regexp.MustCompile(`(?is)^CREATE\s+(?:TEMPORARY\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?` + identifierPattern)
+	dropTablePattern   = regexp.MustCompile(`(?is)^DROP\s+TABLE\s+(?:IF\s+EXISTS\s+)?` + identifierPattern)
+	alterTablePattern  = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:ONLY\s+)?` + identifierPattern + `\s+(.*)$`)
+	createIndexPattern = regexp.MustCompile(`(?is)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+.*?\s+ON\s+` + identifierPattern)
+	dropIndexPattern   = regexp.MustCompile(`(?is)^DROP\s+INDEX\b`)
+	dataPattern        = regexp.MustCompile(`(?is)^(INSERT|UPDATE|DELETE|TRUNCATE)\b(?:\s+(?:INTO|FROM|TABLE))?\s+` + identifierPattern)
+	ignoredPattern     = regexp.MustCompile(`(?is)^(SAVEPOINT|RELEASE|ROLLBACK|SET\s|PRAGMA)`)
+	indexPrefixPattern = regexp.MustCompile(`(?is)^(\s*CREATE\s+(?:UNIQUE\s+)?INDEX)\s+`)
+)
+
+// Plan 生成待执行迁移的计划
+// 功能说明：
+// 1. 在记录模式下执行待执行迁移的 Up，查询正常访问数据库，写操作和DDL只记录不执行
+// 2. 识别删除表/列、修改列、重命名、删除数据等破坏性变更，蓝绿部署时旧版本仍在使用这些结构
+// 3. 根据表的行数估算锁表时间，大表和超过最长锁表时间的变更标记为高风险
+// 4. 计划哈希由驱动、迁移名称和语句计算，用于审批
+func (m *MigrationManager) Plan(options PreflightOptions) (*MigrationPlan, error) {
+	if err := m.CreateMigrationsTable(); err != nil {
+		return nil, fmt.Errorf("创建迁移表失败: %v", err)
+	}
+	pending, err := m.pendingMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &MigrationPlan{
+		Driver:      m.db.Dialector.Name(),
+		GeneratedAt: time.Now(),
+		Migrations:  []PlannedMigration{},
+	}
+	rows := make(map[string]int64)
+	for _, migration := range pending {
+		planned := PlannedMigration{Name: migration.GetName(), Statements: []PlannedStatement{}}
+		recorder := &recordingConnPool{ConnPool: m.connPool()}
+		recordDB := m.session(&gorm.Session{
+			NewDB:                    true,
+			DisableNestedTransaction: true,
+			Logger:                   logger.Discard,
+		}, recorder)
+		if err := migration.Up(recordDB); err != nil {
+			// 无法预检的迁移不能确认影响范围，按破坏性变更处理
+			planned.Error = err.Error()
+			plan.Destructive = true
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("迁移 %s 无法预检: %v", planned.Name, err))
+		}
+
+		for _, recorded := range recorder.statements {
+			query := m.db.Dialector.Explain(recorded.query, recorded.args...)
+			if ignoredPattern.MatchString(strings.TrimSpace(query)) {
+				continue
+			}
+			statement := ClassifyStatement(query)
+			m.estimateLock(&statement, plan.Driver, options, rows)
+			plan.Destructive = plan.Destructive || statement.Destructive
+			plan.HighRisk = plan.HighRisk || statement.HighRisk
+			for _, note := range statement.Notes {
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s: %s", planned.Name, note))
+			}
+			planned.Statements = append(planned.Statements, statement)
+		}
+		plan.Migrations = append(plan.Migrations, planned)
+	}
+	plan.Hash = planHash(plan)
+	return plan, nil
+}
+
+// ClassifyStatement 识别语句类型和是否为破坏性变更
+func ClassifyStatement(query string) PlannedStatement {
+	query = strings.TrimSpace(query)
+	statement := PlannedStatement{SQL: query, Kind: StatementOther}
+
+	switch {
+	case createTablePattern.MatchString(query):
+		statement.Kind = StatementCreateTable
+		statement.Table = createTablePattern.FindStringSubmatch(query)[1]
+	case dropTablePattern.MatchString(query):
+		statement.Kind = StatementDropTable
+		statement.Table = dropTablePattern.FindStringSubmatch(query)[1]
+		statement.Destructive = true
+	case createIndexPattern.MatchString(query):
+		statement.Kind = StatementCreateIndex
+		statement.Table = createIndexPattern.FindStringSubmatch(query)[1]
+	case dropIndexPattern.MatchString(query):
+		statement.Kind = StatementDropIndex
+		statement.Notes = append(statement.Notes, "删除索引可能导致旧版本的查询变慢")
+	case alterTablePattern.MatchString(query):
+		match := alterTablePattern.FindStringSubmatch(query)
+		statement.Table = match[1]
+		classifyAlterTable(&statement, strings.ToUpper(strings.TrimSpace(match[2])))
+	case dataPattern.MatchString(query):
+		match := dataPattern.FindStringSubmatch(query)
+		statement.Kind = StatementData
+		statement.Table = match[2]
+		verb := strings.ToUpper(match[1])
+		statement.Destructive = verb == "DELETE" || verb == "TRUNCATE"
+	}
+	return statement
+}
+
+// classifyAlterTable 识别 ALTER TABLE 的操作
+func classifyAlterTable(statement *PlannedStatement, action string) {
+	switch {
+	case strings.HasPrefix(action, "ADD CONSTRAINT"), strings.HasPrefix(action, "ADD FOREIGN KEY"),
+		strings.HasPrefix(action, "ADD PRIMARY KEY"), strings.HasPrefix(action, "ADD CHECK"):
+		statement.Kind = StatementAddConstraint
+	case strings.HasPrefix(action, "ADD INDEX"), strings.HasPrefix(action, "ADD KEY"),
+		strings.HasPrefix(action, "ADD UNIQUE"), strings.HasPrefix(action, "ADD FULLTEXT"):
+		statement.Kind = StatementCreateIndex
+	case strings.HasPrefix(action, "ADD "):
+		statement.Kind = StatementAddColumn
+		if strings.Contains(action, "NOT NULL") && !strings.Contains(action, "DEFAULT") {
+			statement.Notes = append(statement.Notes, fmt.Sprintf("表 %s 新增的非空列没有默认值，旧版本写入会失败", statement.Table))
+		}
+	case strings.HasPrefix(action, "DROP INDEX"), strings.HasPrefix(action, "DROP KEY"):
+		statement.Kind = StatementDropIndex
+	case strings.HasPrefix(action, "DROP CONSTRAINT"), strings.HasPrefix(action, "DROP FOREIGN KEY"),
+		strings.HasPrefix(action, "DROP PRIMARY KEY"), strings.HasPrefix(action, "DROP CHECK"):
+		statement.Kind = StatementDropConstraint
+	case strings.HasPrefix(action, "DROP "):
+		statement.Kind = StatementDropColumn
+		statement.Destructive = true
+	case strings.HasPrefix(action, "ALTER COLUMN"), strings.HasPrefix(action, "MODIFY"), strings.HasPrefix(action, "CHANGE"):
+		statement.Kind = StatementAlterColumn
+		statement.Destructive = true
+	case strings.HasPrefix(action, "RENAME"):
+		statement.Kind = StatementRename
+		statement.Destructive = true
+	}
+}
+
+// estimateLock 根据表的行数估算锁表时间
+func (m *MigrationManager) estimateLock(statement *PlannedStatement, dialect string, options PreflightOptions, rows map[string]int64) {
+	switch statement.Kind {
+	case StatementCreateIndex:
+		if options.OnlineIndexes && OnlineIndexSQL(dialect, statement.SQL) != statement.SQL {
+			statement.Online = true
+		}
+	case StatementAlterColumn, StatementDropColumn, StatementAddConstraint, StatementData:
+	default:
+		return
+	}
+	if statement.Table == "" {
+		return
+	}
+
+	count, ok := rows[statement.Table]
+	if !ok {
+		count = m.tableRows(dialect, statement.Table)
+		rows[statement.Table] = count
+	}
+	statement.Rows = count
+	if statement.Kind == StatementCreateIndex && !statement.Online {
+		statement.Notes = append(statement.Notes, fmt.Sprintf("%s 不支持在线创建索引，创建期间会阻塞表 %s 的写入", dialect, statement.Table))
+	}
+	if statement.Online || count == 0 || options.RowsPerSecond <= 0 {
+		return
+	}
+
+	lock := time.Duration(float64(count) / float64(options.RowsPerSecond) * float64(time.Second)).Round(time.Millisecond)
+	statement.EstimatedLock = lock.String()
+	if options.LargeTableRows > 0 && count >= options.LargeTableRows {
+		statement.HighRisk = true
+		statement.Notes = append(statement.Notes, fmt.Sprintf("表 %s 约有 %d 行，属于大表", statement.Table, count))
+	}
+	if options.MaxLockTime > 0 && lock > options.MaxLockTime {
+		statement.HighRisk = true
+		statement.Notes = append(statement.Notes, fmt.Sprintf("表 %s 预计锁定 %v，超过 %v", statement.Table, lock, options.MaxLockTime))
+	}
+}
+
+// tableRows 获取表的估算行数，MySQL 和 PostgreSQL 使用统计信息，避免全表扫描
+func (m *MigrationManager) tableRows(dialect, table string) int64 {
+	if !m.db.Migrator().HasTable(table) {
+		return 0
+	}
+	var count int64
+	var err error
+	switch dialect {
+	case "mysql":
+		err = m.db.Raw("SELECT COALESCE(TABLE_ROWS, 0) FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?", table).Scan(&count).Error
+	case "postgres":
+		err = m.db.Raw("SELECT GREATEST(reltuples, 0)::bigint FROM pg_class WHERE relname = ? AND relkind = 'r'", table).Scan(&count).Error
+	default:
+		err = m.db.Table(table).Count(&count).Error
+	}
+	if err != nil {
+		log.Printf("获取表 %s 的行数失败: %v", table, err)
+		return 0
+	}
+	return count
+}
+
+// planHash 计算计划哈希，计划内容变化后需要重新审批
+//
+// GORM 按 map 顺序生成外键约束和关联表，同一迁移的语句和语句内的子句顺序可能不同，
+// 计算哈希前排序，保证相同的迁移生成相同的哈希。
+func planHash(plan *MigrationPlan) string {
+	hash := sha256.New()
+	hash.Write([]byte(plan.Driver + "\n"))
+	for _, migration := range plan.Migrations {
+		hash.Write([]byte("migration:" + migration.Name + "\n"))
+		statements := make([]string, 0, len(migration.Statements))
+		for _, statement := range migration.Statements {
+			clauses := strings.Split(statement.SQL, ",")
+			for i, clause := range clauses {
+				// 最后一个子句带有表定义的右括号
+				clauses[i] = strings.Trim(clause, "() ")
+			}
+			sort.Strings(clauses)
+			statements = append(statements, strings.Join(clauses, ","))
+		}
+		sort.Strings(statements)
+		for _, statement := range statements {
+			hash.Write([]byte(statement + "\n"))
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// OnlineIndexSQL 将创建索引语句改写为在线创建，不支持的数据库返回原语句
+// 功能说明：
+// 1. PostgreSQL 使用 CREATE INDEX CONCURRENTLY，不阻塞写入，但不能在事务中执行
+// 2. MySQL 使用 ALGORITHM=INPLACE, LOCK=NONE，不支持时直接报错而不是退化为锁表
+// 3. SQLite 没有在线创建索引的方式
+func OnlineIndexSQL(dialect, query string) string {
+	if !indexPrefixPattern.MatchString(query) {
+		return query
+	}
+	upper := strings.ToUpper(query)
+	switch dialect {
+	case "postgres":
+		if strings.Contains(upper, "CONCURRENTLY") {
+			return query
+		}
+		return indexPrefixPattern.ReplaceAllString(query, "$1 CONCURRENTLY ")
+	case "mysql":
+		if strings.Contains(upper, "ALGORITHM") {
+			return query
+		}
+		return strings.TrimRight(strings.TrimSpace(query), ";") + " ALGORITHM=INPLACE LOCK=NONE"
+	}
+	return query
+}
+
+// RunMigrationsWithPreflight 预检后运行迁移
+// 功能说明：
+// 1. 安全模式下存在破坏性变更时拒绝执行，除非设置 AllowDestructive
+// 2. 需要审批时检查与当前计划哈希一致、未过期且未使用的审批记录
+// 3. 启用在线索引时执行迁移期间改写创建索引语句
+// 4. 执行成功后标记审批已使用
+func (m *MigrationManager) RunMigrationsWithPreflight(options PreflightOptions) error {
+	plan, err := m.Plan(options)
+	if err != nil {
+		return fmt.Errorf("生成迁移计划失败: %v", err)
+	}
+	if plan.Empty() {
+		log.Println("没有待执行的迁移")
+		return nil
+	}
+
+	if options.SafeMode && plan.Destructive && !options.AllowDestructive {
+		details := plan.DestructiveStatements()
+		for _, migration := range plan.Migrations {
+			if migration.Error != "" {
+				details = append(details, migration.Name+": 无法预检")
+			}
+		}
+		return fmt.Errorf("安全模式下拒绝执行破坏性变更，确认后使用 --allow-destructive 执行:\n  %s", strings.Join(details, "\n  "))
+	}
+
+	var approval *MigrationPlanApproval
+	if options.RequireApproval {
+		if approval, err = m.findApproval(plan.Hash, options.ApprovalTTL); err != nil {
+			return err
+		}
+	}
+
+	exec := m.db
+	if options.OnlineIndexes {
+		exec = m.session(&gorm.Session{NewDB: true}, &onlineIndexConnPool{ConnPool: m.connPool(), dialect: plan.Driver})
+	}
+	if err := m.runMigrations(exec); err != nil {
+		return err
+	}
+
+	if approval != nil {
+		now := time.Now()
+		if err := m.db.Model(approval).Update("used_at", now).Error; err != nil {
+			return fmt.Errorf("更新迁移审批失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// ApprovePlan 审批迁移计划，哈希必须与当前待执行迁移的计划一致
+func (m *MigrationManager) ApprovePlan(hash, approvedBy, comment string, options PreflightOptions) (*MigrationPlanApproval, error) {
+	if strings.TrimSpace(approvedBy) == "" {
+		return nil, fmt.Errorf("审批人不能为空")
+	}
+	plan, err := m.Plan(options)
+	if err != nil {
+		return nil, fmt.Errorf("生成迁移计划失败: %v", err)
+	}
+	if plan.Empty() {
+		return nil, fmt.Errorf("没有待执行的迁移")
+	}
+	if hash != plan.Hash {
+		return nil, fmt.Errorf("计划哈希与当前待执行的迁移不一致，请重新生成迁移计划")
+	}
+	if err := m.ensureApprovalTable(); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(plan.Migrations))
+	for _, migration := range plan.Migrations {
+		names = append(names, migration.Name)
+	}
+	approval := &MigrationPlanApproval{
+		PlanHash:    plan.Hash,
+		Migrations:  strings.Join(names, ","),
+		Destructive: plan.Destructive,
+		ApprovedBy:  approvedBy,
+		Comment:     comment,
+		ApprovedAt:  time.Now(),
+	}
+	if err := m.db.Create(approval).Error; err != nil {
+		return nil, fmt.Errorf("保存迁移审批失败: %v", err)
+	}
+	return approval, nil
+}
+
+// findApproval 查找计划的有效审批
+func (m *MigrationManager) findApproval(hash string, ttl time.Duration) (*MigrationPlanApproval, error) {
+	if err := m.ensureApprovalTable(); err != nil {
+		return nil, err
+	}
+	query := m.db.Where("plan_hash = ? AND used_at IS NULL", hash)
+	if ttl > 0 {
+		query = query.Where("approved_at >= ?", time.Now().Add(-ttl))
+	}
+	var approval MigrationPlanApproval
+	if err := query.Order("approved_at desc").First(&approval).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("迁移计划 %s 未审批或审批已失效，请先执行 migrate plan 并使用 migrate approve 审批", hash)
+		}
+		return nil, fmt.Errorf("查询迁移审批失败: %v", err)
+	}
+	return &approval, nil
+}
+
+// ensureApprovalTable 确保审批表存在
+func (m *MigrationManager) ensureApprovalTable() error {
+	if m.db.Migrator().HasTable(&MigrationPlanApproval{}) {
+		return nil
+	}
+	if err := m.db.AutoMigrate(&MigrationPlanApproval{}); err != nil {
+		return fmt.Errorf("创建迁移审批表失败: %v", err)
+	}
+	return nil
+}
+
+// connPool 获取底层连接池
+func (m *MigrationManager) connPool() gorm.ConnPool {
+	if m.db.Statement != nil && m.db.Statement.ConnPool != nil {
+		return m.db.Statement.ConnPool
+	}
+	return m.db.ConnPool
+}
+
+// session 创建使用指定连接池的会话
+//
+// 设置 Context 使 GORM 复制 Statement，避免修改 m.db 的连接池。
+func (m *MigrationManager) session(config *gorm.Session, pool gorm.ConnPool) *gorm.DB {
+	config.Context = context.Background()
+	if m.db.Statement != nil && m.db.Statement.Context != nil {
+		config.Context = m.db.Statement.Context
+	}
+	db := m.db.Session(config)
+	db.Statement.ConnPool = pool
+	return db
+}
+
+// recordedStatement 记录的写语句
+type recordedStatement struct {
+	query string
+	args  []interface{}
+}
+
+// recordingConnPool 预检用的连接池，查询访问数据库，写操作只记录不执行
+//
+// 实现 Commit/Rollback 使 GORM 将其视为已在事务中，配合 DisableNestedTransaction
+// 迁移中的 Transaction 直接执行回调；不实现 GetDBConn，需要底层连接的操作会报错而不会绕过记录。
+type recordingConnPool struct {
+	gorm.ConnPool
+	statements []recordedStatement
+}
+
+func (p *recordingConnPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	p.statements = append(p.statements, recordedStatement{query: query, args: args})
+	return driver.RowsAffected(0), nil
+}
+
+func (p *recordingConnPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, fmt.Errorf("迁移预检不支持预编译语句")
+}
+
+func (p *recordingConnPool) Commit() error   { return nil }
+func (p *recordingConnPool) Rollback() error { return nil }
+
+// onlineIndexConnPool 执行迁移时将创建索引语句改写为在线创建
+type onlineIndexConnPool struct {
+	gorm.ConnPool
+	dialect string
+}
+
+func (p *onlineIndexConnPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return p.ConnPool.ExecContext(ctx, OnlineIndexSQL(p.dialect, query), args...)
+}
+
+func (p *onlineIndexConnPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	switch beginner := p.ConnPool.(type) {
+	case gorm.TxBeginner:
+		tx, err := beginner.BeginTx(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &onlineIndexTx{onlineIndexConnPool{ConnPool: tx, dialect: p.dialect}}, nil
+	case gorm.ConnPoolBeginner:
+		tx, err := beginner.BeginTx(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &onlineIndexTx{onlineIndexConnPool{ConnPool: tx, dialect: p.dialect}}, nil
+	}
+	return nil, gorm.ErrInvalidTransaction
+}
+
+// GetDBConn 供 GORM 获取底层连接，如 MySQL 删除表时使用
+func (p *onlineIndexConnPool) GetDBConn() (*sql.DB, error) {
+	if db, ok := p.ConnPool.(*sql.DB); ok {
+		return db, nil
+	}
+	if connector, ok := p.ConnPool.(gorm.GetDBConnector); ok {
+		return connector.GetDBConn()
+	}
+	return nil, gorm.ErrInvalidDB
+}
+
+// onlineIndexTx 事务中改写创建索引语句
+//
+// PostgreSQL 的 CREATE INDEX CONCURRENTLY 不能在事务中执行，事务内的语句不改写。
+type onlineIndexTx struct {
+	onlineIndexConnPool
+}
+
+func (t *onlineIndexTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if t.dialect == "postgres" {
+		return t.ConnPool.ExecContext(ctx, query, args...)
+	}
+	return t.onlineIndexConnPool.ExecContext(ctx, query, args...)
+}
+
+func (t *onlineIndexTx) Commit() error {
+	if committer, ok := t.ConnPool.(gorm.TxCommitter); ok {
+		return committer.Commit()
+	}
+	return gorm.ErrInvalidTransaction
+}
+
+func (t *onlineIndexTx) Rollback() error {
+	if committer, ok := t.ConnPool.(gorm.TxCommitter); ok {
+		return committer.Rollback()
+	}
+	return gorm.ErrInvalidTransaction
+}
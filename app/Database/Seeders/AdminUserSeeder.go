@@ -0,0 +1,49 @@
+package Seeders
+
+import (
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Utils"
+	"fmt"
+	"strings"
+)
+
+// AdminUserSeeder 管理员填充器
+// 功能说明：
+// 1. 按 Options 创建管理员，用户名已存在时跳过，不修改已有账号的密码
+// 2. 密码需满足密码强度要求，使用生成的密码时要求首次登录后修改
+type AdminUserSeeder struct{}
+
+// GetName 获取填充器名称
+func (s *AdminUserSeeder) GetName() string {
+	return "admin_user"
+}
+
+// Run 填充管理员
+func (s *AdminUserSeeder) Run(ctx *Context) error {
+	username := strings.TrimSpace(ctx.Options.AdminUsername)
+	email := strings.TrimSpace(ctx.Options.AdminEmail)
+	if username == "" || email == "" {
+		return fmt.Errorf("管理员用户名和邮箱不能为空")
+	}
+	if ok, problems := Utils.ValidatePasswordStrength(ctx.Options.AdminPassword); !ok {
+		return fmt.Errorf("管理员密码强度不足: %s", strings.Join(problems, "; "))
+	}
+
+	admin, created, err := createUser(ctx, &Models.User{
+		Username:           username,
+		Email:              email,
+		Role:               "admin",
+		Status:             1,
+		MustChangePassword: ctx.Options.AdminMustChangePassword,
+	}, ctx.Options.AdminPassword)
+	if err != nil {
+		return err
+	}
+	if !created {
+		ctx.Notef("管理员 %s 已存在，跳过", admin.Username)
+		return nil
+	}
+	ctx.Notef("创建管理员 %s", admin.Username)
+	ctx.AddCredential("admin", admin.Username, ctx.Options.AdminPassword)
+	return nil
+}
@@ -0,0 +1,63 @@
+package Seeders
+
+import (
+	"cloud-platform-api/app/Models"
+	"fmt"
+)
+
+// SampleAlertRules 示例告警规则，覆盖CPU、内存、磁盘和错误率
+func SampleAlertRules() []Models.AlertRule {
+	return []Models.AlertRule{
+		{Name: "cpu_usage_high", Description: "CPU使用率持续超过80%", MetricType: "system", MetricName: "cpu_usage", Condition: ">", Threshold: 80, Duration: 3, Severity: "warning"},
+		{Name: "memory_usage_critical", Description: "内存使用率超过90%", MetricType: "system", MetricName: "memory_usage", Condition: ">", Threshold: 90, Duration: 2, Severity: "critical"},
+		{Name: "disk_usage_high", Description: "磁盘使用率超过85%", MetricType: "system", MetricName: "disk_usage", Condition: ">", Threshold: 85, Duration: 1, Severity: "warning"},
+		{Name: "http_error_rate_high", Description: "HTTP 5xx 错误率超过5%", MetricType: "application", MetricName: "http_error_rate", Condition: ">", Threshold: 5, Duration: 2, Severity: "critical", Escalation: true},
+	}
+}
+
+// AlertRuleSeeder 示例告警规则填充器
+// 功能说明：
+// 1. 创建 SampleAlertRules 中的阈值告警规则，归属默认运维团队，团队成员可以调整阈值
+// 2. 同名规则已存在时跳过，不覆盖已调整的阈值
+type AlertRuleSeeder struct{}
+
+// GetName 获取填充器名称
+func (s *AlertRuleSeeder) GetName() string {
+	return "alert_rules"
+}
+
+// Run 填充示例告警规则
+func (s *AlertRuleSeeder) Run(ctx *Context) error {
+	var admin Models.User
+	if err := ctx.DB.Where("username = ?", ctx.Options.AdminUsername).First(&admin).Error; err != nil {
+		return fmt.Errorf("管理员 %s 不存在，请先执行 admin_user: %v", ctx.Options.AdminUsername, err)
+	}
+	var teamID *uint
+	var team Models.Team
+	if err := ctx.DB.Joins("JOIN organizations ON organizations.id = teams.organization_id").
+		Where("organizations.name = ? AND teams.name = ?", DefaultOrganizationName, DefaultTeamName).
+		First(&team).Error; err == nil {
+		teamID = &team.ID
+	}
+
+	for _, rule := range SampleAlertRules() {
+		rule.Type = "threshold"
+		rule.Enabled = true
+		rule.SuppressionWindow = 3600
+		rule.EscalationDelay = 600
+		rule.MaxEscalationLevel = 3
+		rule.CreatedBy = admin.ID
+		rule.TeamID = teamID
+		// 回收站中的同名规则也视为已存在，避免唯一索引冲突
+		result := ctx.DB.Unscoped().Where(Models.AlertRule{Name: rule.Name}).Attrs(rule).FirstOrCreate(&Models.AlertRule{})
+		if result.Error != nil {
+			return fmt.Errorf("创建告警规则 %s 失败: %v", rule.Name, result.Error)
+		}
+		if result.RowsAffected > 0 {
+			ctx.Notef("创建告警规则 %s", rule.Name)
+		} else {
+			ctx.Notef("告警规则 %s 已存在，跳过", rule.Name)
+		}
+	}
+	return nil
+}
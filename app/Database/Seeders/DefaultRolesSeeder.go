@@ -0,0 +1,78 @@
+package Seeders
+
+import (
+	"cloud-platform-api/app/Models"
+	"fmt"
+)
+
+// 默认组织和团队
+const (
+	DefaultOrganizationName = "Default"
+	DefaultTeamName         = "ops"
+)
+
+// DefaultRolesSeeder 默认角色填充器
+// 功能说明：
+// 1. 平台角色固定为 admin 和 user，团队角色为 admin 和 member
+// 2. 创建默认组织和运维团队，管理员为组织所有者和团队管理员，团队成员可以共同管理告警规则
+// 3. 依赖 AdminUserSeeder 创建的管理员，已存在的组织、团队和成员跳过
+type DefaultRolesSeeder struct{}
+
+// GetName 获取填充器名称
+func (s *DefaultRolesSeeder) GetName() string {
+	return "default_roles"
+}
+
+// Run 填充默认组织、团队和团队角色
+func (s *DefaultRolesSeeder) Run(ctx *Context) error {
+	var admin Models.User
+	if err := ctx.DB.Where("username = ?", ctx.Options.AdminUsername).First(&admin).Error; err != nil {
+		return fmt.Errorf("管理员 %s 不存在，请先执行 admin_user: %v", ctx.Options.AdminUsername, err)
+	}
+	team, err := ensureTeam(ctx, DefaultOrganizationName, "默认组织", DefaultTeamName, "运维团队，负责告警处理", admin.ID)
+	if err != nil {
+		return err
+	}
+	return ensureMember(ctx, team, admin, Models.TeamRoleAdmin)
+}
+
+// ensureTeam 创建组织和团队，已存在时返回已有的团队
+func ensureTeam(ctx *Context, organizationName, organizationDescription, teamName, teamDescription string, ownerID uint) (*Models.Team, error) {
+	var organization Models.Organization
+	result := ctx.DB.Where(Models.Organization{Name: organizationName}).
+		Attrs(Models.Organization{Description: organizationDescription, OwnerID: ownerID}).
+		FirstOrCreate(&organization)
+	if result.Error != nil {
+		return nil, fmt.Errorf("创建组织 %s 失败: %v", organizationName, result.Error)
+	}
+	if result.RowsAffected > 0 {
+		ctx.Notef("创建组织 %s", organizationName)
+	}
+
+	var team Models.Team
+	result = ctx.DB.Where(Models.Team{OrganizationID: organization.ID, Name: teamName}).
+		Attrs(Models.Team{Description: teamDescription, CreatedBy: ownerID}).
+		FirstOrCreate(&team)
+	if result.Error != nil {
+		return nil, fmt.Errorf("创建团队 %s 失败: %v", teamName, result.Error)
+	}
+	if result.RowsAffected > 0 {
+		ctx.Notef("创建团队 %s/%s", organizationName, teamName)
+	}
+	return &team, nil
+}
+
+// ensureMember 添加团队成员，已是成员时不修改角色
+func ensureMember(ctx *Context, team *Models.Team, user Models.User, role string) error {
+	var member Models.TeamMember
+	result := ctx.DB.Where(Models.TeamMember{TeamID: team.ID, UserID: user.ID}).
+		Attrs(Models.TeamMember{Role: role}).
+		FirstOrCreate(&member)
+	if result.Error != nil {
+		return fmt.Errorf("添加团队成员 %s 失败: %v", user.Username, result.Error)
+	}
+	if result.RowsAffected > 0 {
+		ctx.Notef("%s 加入团队 %s，角色 %s", user.Username, team.Name, role)
+	}
+	return nil
+}
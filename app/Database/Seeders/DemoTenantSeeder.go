@@ -0,0 +1,259 @@
+package Seeders
+
+import (
+	"cloud-platform-api/app/Models"
+	"fmt"
+	"math"
+	"time"
+)
+
+// 演示租户
+const (
+	DemoOrganizationName = "Demo"
+	DemoTeamName         = "demo-ops"
+	DemoHistory          = 24 * time.Hour  // 指标历史的时长
+	DemoSampleInterval   = 5 * time.Minute // 指标历史的采样间隔
+)
+
+// demoUsers 演示用户：平台管理员、团队管理员和只读成员
+var demoUsers = []struct {
+	Username string
+	Role     string
+	TeamRole string
+}{
+	{"demo-admin", "admin", Models.TeamRoleAdmin},
+	{"demo-operator", "user", Models.TeamRoleAdmin},
+	{"demo-viewer", "user", Models.TeamRoleMember},
+}
+
+// demoMetrics 演示指标：名称、类型、单位、基准值和波动幅度
+var demoMetrics = []struct {
+	Name      string
+	Type      string
+	Unit      string
+	Base      float64
+	Amplitude float64
+	Threshold float64
+}{
+	{"cpu_usage", "system", "%", 55, 30, 80},
+	{"memory_usage", "system", "%", 70, 12, 90},
+	{"disk_usage", "system", "%", 62, 2, 85},
+	{"http_request_rate", "application", "req/s", 120, 80, 0},
+	{"http_error_rate", "application", "%", 1.5, 1.2, 5},
+}
+
+// DemoTenantSeeder 演示租户填充器
+// 功能说明：
+// 1. 创建演示组织、团队和三个演示用户（平台管理员、团队管理员、只读成员），密码为 Options.DemoPassword
+// 2. 按 DemoSampleInterval 生成过去 DemoHistory 的指标历史和最新指标，数值按日周期波动，每次生成的数据相同
+// 3. 生成部署、配置变更等监控事件，登录失败等安全事件，以及示例告警规则触发的活动告警和已解决告警
+// 4. 演示组织已存在时跳过，避免重复生成历史数据
+type DemoTenantSeeder struct{}
+
+// GetName 获取填充器名称
+func (s *DemoTenantSeeder) GetName() string {
+	return "demo_tenant"
+}
+
+// Run 填充演示租户
+func (s *DemoTenantSeeder) Run(ctx *Context) error {
+	var count int64
+	if err := ctx.DB.Model(&Models.Organization{}).Where("name = ?", DemoOrganizationName).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		ctx.Notef("演示组织 %s 已存在，跳过", DemoOrganizationName)
+		return nil
+	}
+	if ctx.Options.DemoPassword == "" {
+		return fmt.Errorf("演示用户密码不能为空")
+	}
+
+	users := make([]Models.User, 0, len(demoUsers))
+	for _, demo := range demoUsers {
+		user, created, err := createUser(ctx, &Models.User{
+			Username: demo.Username,
+			Email:    demo.Username + "@demo.example.com",
+			Role:     demo.Role,
+			Status:   1,
+		}, ctx.Options.DemoPassword)
+		if err != nil {
+			return err
+		}
+		if created {
+			ctx.Notef("创建演示用户 %s", user.Username)
+			ctx.AddCredential(demo.Role, user.Username, ctx.Options.DemoPassword)
+		}
+		users = append(users, *user)
+	}
+
+	team, err := ensureTeam(ctx, DemoOrganizationName, "演示组织", DemoTeamName, "演示团队", users[0].ID)
+	if err != nil {
+		return err
+	}
+	for i, demo := range demoUsers {
+		if err := ensureMember(ctx, team, users[i], demo.TeamRole); err != nil {
+			return err
+		}
+	}
+
+	if err := s.seedMetrics(ctx); err != nil {
+		return err
+	}
+	if err := s.seedEvents(ctx); err != nil {
+		return err
+	}
+	if err := s.seedSecurityEvents(ctx); err != nil {
+		return err
+	}
+	return s.seedAlerts(ctx, users[1])
+}
+
+// demoValue 演示指标在 t 时刻的值，按日周期波动
+func demoValue(base, amplitude float64, t time.Time) float64 {
+	hour := float64(t.Hour()) + float64(t.Minute())/60
+	value := base + amplitude*math.Sin((hour-8)/24*2*math.Pi)
+	return math.Round(math.Max(value, 0)*100) / 100
+}
+
+// seedMetrics 生成指标历史和最新指标
+func (s *DemoTenantSeeder) seedMetrics(ctx *Context) error {
+	end := ctx.Now.Truncate(DemoSampleInterval)
+	points := int(DemoHistory / DemoSampleInterval)
+	samples := make([]Models.MetricSample, 0, points*len(demoMetrics))
+	for _, metric := range demoMetrics {
+		for i := points - 1; i >= 0; i-- {
+			timestamp := end.Add(-time.Duration(i) * DemoSampleInterval)
+			samples = append(samples, Models.MetricSample{
+				Name:      metric.Name,
+				Value:     demoValue(metric.Base, metric.Amplitude, timestamp),
+				Timestamp: timestamp,
+			})
+		}
+
+		value := demoValue(metric.Base, metric.Amplitude, end)
+		status, severity := "normal", "info"
+		if metric.Threshold > 0 && value > metric.Threshold {
+			status, severity = "warning", "warning"
+		}
+		if err := ctx.DB.Create(&Models.MonitoringMetric{
+			Type:        metric.Type,
+			Name:        metric.Name,
+			Value:       value,
+			Unit:        metric.Unit,
+			Threshold:   metric.Threshold,
+			Status:      status,
+			Severity:    severity,
+			Description: "演示数据",
+			Tags:        `{"tenant":"demo"}`,
+			Timestamp:   end,
+		}).Error; err != nil {
+			return fmt.Errorf("创建演示指标 %s 失败: %v", metric.Name, err)
+		}
+	}
+	if err := ctx.DB.CreateInBatches(samples, 500).Error; err != nil {
+		return fmt.Errorf("创建演示指标历史失败: %v", err)
+	}
+	ctx.Notef("生成 %d 个指标的 %d 条历史采样", len(demoMetrics), len(samples))
+	return nil
+}
+
+// seedEvents 生成监控事件
+func (s *DemoTenantSeeder) seedEvents(ctx *Context) error {
+	events := []Models.MonitoringEvent{
+		{Type: "deployment", Category: "change", Source: "ci", Severity: "info", Message: "部署 v1.4.2 到生产环境", Timestamp: ctx.Now.Add(-20 * time.Hour)},
+		{Type: "config_change", Category: "change", Source: "cloudctl", Severity: "info", Message: "调整数据库连接池大小为 50", Timestamp: ctx.Now.Add(-9 * time.Hour)},
+		{Type: "scaling", Category: "capacity", Source: "autoscaler", Severity: "warning", Message: "API 实例数从 3 扩容到 5", Timestamp: ctx.Now.Add(-3 * time.Hour)},
+		{Type: "deployment", Category: "change", Source: "ci", Severity: "info", Message: "部署 v1.4.3 到生产环境", Timestamp: ctx.Now.Add(-40 * time.Minute)},
+	}
+	for i := range events {
+		events[i].Tags = `{"tenant":"demo"}`
+		events[i].Processed = true
+	}
+	if err := ctx.DB.Create(&events).Error; err != nil {
+		return fmt.Errorf("创建演示事件失败: %v", err)
+	}
+	ctx.Notef("生成 %d 条监控事件", len(events))
+	return nil
+}
+
+// seedSecurityEvents 生成安全事件：同一IP的连续登录失败和一次成功登录
+func (s *DemoTenantSeeder) seedSecurityEvents(ctx *Context) error {
+	var events []Models.SecurityEvent
+	for i := 0; i < 5; i++ {
+		events = append(events, Models.SecurityEvent{
+			EventType:  "login_failed",
+			EventLevel: "medium",
+			Username:   "demo-viewer",
+			IPAddress:  "203.0.113.24",
+			Resource:   "/api/v1/auth/login",
+			Action:     "login",
+			Details:    "密码错误",
+			RiskScore:  float64(30 + i*10),
+			CreatedAt:  ctx.Now.Add(-time.Duration(30-i) * time.Minute),
+		})
+	}
+	events = append(events, Models.SecurityEvent{
+		EventType:  "login_success",
+		EventLevel: "low",
+		Username:   "demo-admin",
+		IPAddress:  "198.51.100.7",
+		Resource:   "/api/v1/auth/login",
+		Action:     "login",
+		CreatedAt:  ctx.Now.Add(-10 * time.Minute),
+	})
+	if err := ctx.DB.Create(&events).Error; err != nil {
+		return fmt.Errorf("创建演示安全事件失败: %v", err)
+	}
+	ctx.Notef("生成 %d 条安全事件", len(events))
+	return nil
+}
+
+// seedAlerts 按示例告警规则生成活动告警和已解决告警，规则不存在时跳过
+func (s *DemoTenantSeeder) seedAlerts(ctx *Context, resolver Models.User) error {
+	var rules []Models.AlertRule
+	if err := ctx.DB.Where("name IN ?", []string{"cpu_usage_high", "memory_usage_critical"}).Order("name").Find(&rules).Error; err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		ctx.Notef("示例告警规则不存在，跳过告警")
+		return nil
+	}
+
+	for _, rule := range rules {
+		firedAt := ctx.Now.Add(-15 * time.Minute)
+		alert := Models.Alert{
+			RuleID:      rule.ID,
+			RuleName:    rule.Name,
+			Type:        rule.Type,
+			MetricType:  rule.MetricType,
+			MetricName:  rule.MetricName,
+			Value:       rule.Threshold + 6,
+			Threshold:   rule.Threshold,
+			Severity:    rule.Severity,
+			Status:      "active",
+			Message:     fmt.Sprintf("%s %s %v", rule.MetricName, rule.Condition, rule.Threshold),
+			Description: rule.Description,
+			Fingerprint: fmt.Sprintf("demo-%s", rule.Name),
+			Metadata:    `{"tenant":"demo"}`,
+			Count:       3,
+			FiredAt:     firedAt,
+			LastSeenAt:  ctx.Now,
+		}
+		if rule.Name == "memory_usage_critical" {
+			firedAt = ctx.Now.Add(-6 * time.Hour)
+			resolvedAt := firedAt.Add(25 * time.Minute)
+			alert.Status = "resolved"
+			alert.Count = 1
+			alert.FiredAt = firedAt
+			alert.LastSeenAt = firedAt
+			alert.ResolvedAt = &resolvedAt
+			alert.ResolvedBy = &resolver.ID
+		}
+		if err := ctx.DB.Create(&alert).Error; err != nil {
+			return fmt.Errorf("创建演示告警失败: %v", err)
+		}
+	}
+	ctx.Notef("生成 %d 条告警", len(rules))
+	return nil
+}
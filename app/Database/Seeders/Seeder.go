@@ -0,0 +1,187 @@
+package Seeders
+
+import (
+	"cloud-platform-api/app/Models"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Seeder 数据填充接口
+//
+// Run 需要可以重复执行：已存在的数据跳过而不是重复创建或覆盖。
+type Seeder interface {
+	GetName() string
+	Run(ctx *Context) error
+}
+
+// Options 数据填充选项
+type Options struct {
+	AdminUsername           string // 管理员用户名
+	AdminEmail              string // 管理员邮箱
+	AdminPassword           string // 管理员密码
+	AdminMustChangePassword bool   // 管理员首次登录后是否必须修改密码，使用生成的密码时设置
+	DemoPassword            string // 演示用户的密码
+}
+
+// Credential 填充时创建的账号
+type Credential struct {
+	Role     string `json:"role"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// SeederResult 单个填充器的执行结果
+type SeederResult struct {
+	Name  string   `json:"name"`
+	Notes []string `json:"notes"`
+}
+
+// Report 填充结果
+type Report struct {
+	Environment string         `json:"environment"`
+	Seeders     []SeederResult `json:"seeders"`
+	Credentials []Credential   `json:"credentials,omitempty"`
+}
+
+// Context 填充上下文
+type Context struct {
+	DB      *gorm.DB
+	Options Options
+	Now     time.Time
+	report  *Report
+	result  *SeederResult
+}
+
+// Notef 记录填充说明，如创建或跳过的数据
+func (c *Context) Notef(format string, args ...interface{}) {
+	c.result.Notes = append(c.result.Notes, fmt.Sprintf(format, args...))
+}
+
+// AddCredential 记录创建的账号，填充结束后输出一次
+func (c *Context) AddCredential(role, username, password string) {
+	c.report.Credentials = append(c.report.Credentials, Credential{Role: role, Username: username, Password: password})
+}
+
+// 填充环境
+const (
+	EnvironmentProduction  = "production"
+	EnvironmentStaging     = "staging"
+	EnvironmentDevelopment = "development"
+	EnvironmentTesting     = "testing"
+	EnvironmentDemo        = "demo"
+)
+
+// ForEnvironment 获取环境对应的填充器，按依赖顺序排列
+// 功能说明：
+// 1. 生产和预发环境只填充管理员、默认角色和示例告警规则
+// 2. 开发、测试和演示环境另外填充演示租户（用户、指标历史、事件、告警和安全事件）
+func ForEnvironment(environment string) []Seeder {
+	seeders := []Seeder{&AdminUserSeeder{}, &DefaultRolesSeeder{}, &AlertRuleSeeder{}}
+	switch strings.ToLower(environment) {
+	case EnvironmentProduction, EnvironmentStaging:
+		return seeders
+	}
+	return append(seeders, &DemoTenantSeeder{})
+}
+
+// Select 按名称选择填充器，保持原有顺序，names 为空时返回全部
+func Select(seeders []Seeder, names []string) ([]Seeder, error) {
+	if len(names) == 0 {
+		return seeders, nil
+	}
+	available := make(map[string]bool, len(seeders))
+	for _, seeder := range seeders {
+		available[seeder.GetName()] = true
+	}
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if !available[name] {
+			return nil, fmt.Errorf("当前环境没有填充器 %s", name)
+		}
+		wanted[name] = true
+	}
+	var selected []Seeder
+	for _, seeder := range seeders {
+		if wanted[seeder.GetName()] {
+			selected = append(selected, seeder)
+		}
+	}
+	return selected, nil
+}
+
+// Tables 填充器写入的模型，用于测试中创建表
+func Tables() []interface{} {
+	return []interface{}{
+		&Models.User{},
+		&Models.Organization{},
+		&Models.Team{},
+		&Models.TeamMember{},
+		&Models.AlertRule{},
+		&Models.Alert{},
+		&Models.MonitoringMetric{},
+		&Models.MetricSample{},
+		&Models.MonitoringEvent{},
+		&Models.SecurityEvent{},
+	}
+}
+
+// SeederManager 数据填充管理器
+// 功能说明：
+// 1. 按顺序执行填充器，每个填充器在单独的事务中执行，失败时回滚该填充器写入的数据
+// 2. 填充器可以重复执行，已存在的数据跳过
+// 3. 返回每个填充器的说明和新创建的账号
+type SeederManager struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+// NewSeederManager 创建数据填充管理器
+func NewSeederManager(db *gorm.DB) *SeederManager {
+	return &SeederManager{db: db, now: time.Now}
+}
+
+// Run 执行填充器
+func (m *SeederManager) Run(environment string, seeders []Seeder, options Options) (*Report, error) {
+	report := &Report{Environment: environment, Seeders: []SeederResult{}}
+	for _, seeder := range seeders {
+		result := SeederResult{Name: seeder.GetName(), Notes: []string{}}
+		ctx := &Context{Options: options, Now: m.now(), report: report, result: &result}
+		credentials := len(report.Credentials)
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			ctx.DB = tx
+			return seeder.Run(ctx)
+		})
+		if err != nil {
+			// 回滚后不再输出该填充器创建的账号
+			report.Credentials = report.Credentials[:credentials]
+			return report, fmt.Errorf("填充器 %s 执行失败: %v", seeder.GetName(), err)
+		}
+		report.Seeders = append(report.Seeders, result)
+	}
+	return report, nil
+}
+
+// createUser 创建用户，用户名已存在时返回已有用户和 false
+func createUser(ctx *Context, user *Models.User, password string) (*Models.User, bool, error) {
+	var existing Models.User
+	err := ctx.DB.Where("username = ?", user.Username).First(&existing).Error
+	if err == nil {
+		return &existing, false, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, false, err
+	}
+	if err := user.SetPassword(password); err != nil {
+		return nil, false, fmt.Errorf("设置密码失败: %v", err)
+	}
+	now := ctx.Now
+	user.PasswordChangedAt = &now
+	if err := ctx.DB.Create(user).Error; err != nil {
+		return nil, false, fmt.Errorf("创建用户 %s 失败: %v", user.Username, err)
+	}
+	return user, true, nil
+}
@@ -5,6 +5,7 @@ import (
 	"cloud-platform-api/app/Database/Migrations"
 	"cloud-platform-api/app/Storage"
 	"context"
+	"database/sql"
 	"fmt"
 	"log"
 	"math"
@@ -38,17 +39,20 @@ type LogManagerInterface interface {
 	LogBusiness(ctx context.Context, module string, action string, message string, fields map[string]interface{})
 }
 
+// GormLoggerProvider 提供GORM日志适配器的日志管理器
+// 适配器同时实现 gorm.Plugin 时在连接建立后注册
+type GormLoggerProvider interface {
+	GormLogger() logger.Interface
+}
+
 // InitDBWithLogManager 使用指定的LogManagerService初始化数据库连接
 func InitDBWithLogManager(logManager LogManagerInterface) {
-	initDB()
+	// 连接时就使用日志管理器的GORM日志记录器，迁移和后台服务的SQL同样写入SQL日志
+	gormLogger := gormLoggerFor(logManager)
+	initDB(gormLogger)
+	useGormLoggerPlugin(gormLogger)
 
-	// 如果提供了LogManagerService，则设置GORM日志记录器
 	if logManager != nil {
-		// 设置GORM日志记录器
-		DB.Logger = &GormLogManagerWrapper{
-			logManager: logManager,
-		}
-
 		// 记录数据库连接成功日志到数据库日志中
 		cfg := Config.GetConfig().Database
 		logManager.LogSQL(context.Background(), "数据库连接成功", 0, 0, nil, map[string]interface{}{
@@ -403,7 +407,7 @@ func calculateRetryDelay(baseDelay time.Duration, attempt int, maxDelay time.Dur
 // - 连接失败会记录致命错误并退出程序（log.Fatal）
 // - 连接池配置失败会立即退出
 // - 连接测试失败会立即退出
-func initDB() {
+func initDB(gormLogger logger.Interface) {
 	var err error
 	maxRetries := 5                   // 最大重试次数
 	baseRetryDelay := 2 * time.Second // 初始重试延迟
@@ -416,31 +420,13 @@ func initDB() {
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		// 根据数据库驱动类型建立连接
 		// 支持MySQL、PostgreSQL、SQLite三种数据库
-		switch cfg.Driver {
-		case "mysql":
-			dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=%s&parseTime=True&loc=Local&timeout=30s&readTimeout=30s&writeTimeout=30s",
-				cfg.Username, cfg.Password, cfg.Host, cfg.Port, cfg.Database, cfg.Charset)
-			DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
-				Logger: getGormLogger(),
-			})
-
-		case "postgres":
-			dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Shanghai connect_timeout=30",
-				cfg.Host, cfg.Username, cfg.Password, cfg.Database, cfg.Port)
-			DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
-				Logger: getGormLogger(),
-			})
-
-		case "sqlite":
-			// 使用纯 Go 的 SQLite 驱动，不需要 CGO
-			dsn := fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)", cfg.Database)
-			DB, err = gorm.Open(sqlite.Open(dsn), &gorm.Config{
-				Logger: getGormLogger(),
-			})
-
-		default:
+		dialector := newDialector(cfg)
+		if dialector == nil {
 			log.Fatal("Unsupported database driver:", cfg.Driver)
 		}
+		DB, err = gorm.Open(dialector, &gorm.Config{
+			Logger: gormLogger,
+		})
 
 		// 检查连接是否成功
 		if err == nil {
@@ -487,11 +473,7 @@ func initDB() {
 	}
 
 	// 设置连接池参数 - 优化性能和资源使用
-	// 这些参数对高并发场景下的性能至关重要
-	sqlDB.SetMaxIdleConns(20)                  // 最大空闲连接数：保持20个连接在池中，减少连接创建开销
-	sqlDB.SetMaxOpenConns(200)                 // 最大打开连接数：最多同时打开200个连接，支持高并发
-	sqlDB.SetConnMaxLifetime(30 * time.Minute) // 连接最大生命周期：30分钟后强制关闭连接，防止连接老化
-	sqlDB.SetConnMaxIdleTime(5 * time.Minute)  // 空闲连接最大生存时间：5分钟未使用则关闭，释放资源
+	configureConnectionPool(sqlDB)
 
 	// 测试数据库连接
 	// Ping()会发送一个简单的查询来验证连接是否有效
@@ -509,9 +491,95 @@ func initDB() {
 	log.Println("Database connected successfully")
 }
 
+// newDialector 根据数据库驱动创建GORM方言，不支持的驱动返回nil
+func newDialector(cfg Config.DatabaseConfig) gorm.Dialector {
+	switch cfg.Driver {
+	case "mysql":
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=%s&parseTime=True&loc=Local&timeout=30s&readTimeout=30s&writeTimeout=30s",
+			cfg.Username, cfg.Password, cfg.Host, cfg.Port, cfg.Database, cfg.Charset)
+		return mysql.Open(dsn)
+
+	case "postgres":
+		dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Shanghai connect_timeout=30",
+			cfg.Host, cfg.Username, cfg.Password, cfg.Database, cfg.Port)
+		return postgres.Open(dsn)
+
+	case "sqlite":
+		// 使用纯 Go 的 SQLite 驱动，不需要 CGO
+		dsn := fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)", cfg.Database)
+		return sqlite.Open(dsn)
+
+	default:
+		return nil
+	}
+}
+
+// configureConnectionPool 设置连接池参数
+// 这些参数对高并发场景下的性能至关重要
+func configureConnectionPool(sqlDB *sql.DB) {
+	sqlDB.SetMaxIdleConns(20)                  // 最大空闲连接数：保持20个连接在池中，减少连接创建开销
+	sqlDB.SetMaxOpenConns(200)                 // 最大打开连接数：最多同时打开200个连接，支持高并发
+	sqlDB.SetConnMaxLifetime(30 * time.Minute) // 连接最大生命周期：30分钟后强制关闭连接，防止连接老化
+	sqlDB.SetConnMaxIdleTime(5 * time.Minute)  // 空闲连接最大生存时间：5分钟未使用则关闭，释放资源
+}
+
+// Ping 使用临时连接检查数据库是否可用，不影响全局连接
+// 用于启动时等待数据库就绪
+func Ping(ctx context.Context) error {
+	dialector := newDialector(Config.GetConfig().Database)
+	if dialector == nil {
+		return fmt.Errorf("不支持的数据库驱动: %s", Config.GetConfig().Database.Driver)
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger:               logger.Discard,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+	return sqlDB.PingContext(ctx)
+}
+
+// InitDBDeferredWithLogManager 数据库不可用时以降级模式初始化数据库连接
+// 功能说明：
+// 1. 不测试连接也不重试，连接池在数据库恢复后自动建立连接
+// 2. 数据库恢复前的查询返回连接错误，由启动编排服务暂停依赖数据库的路由
+// 3. 数据库恢复后需要调用 AutoMigrate 执行迁移
+func InitDBDeferredWithLogManager(logManager LogManagerInterface) {
+	cfg := Config.GetConfig().Database
+	dialector := newDialector(cfg)
+	if dialector == nil {
+		log.Fatal("Unsupported database driver:", cfg.Driver)
+	}
+
+	var err error
+	gormLogger := gormLoggerFor(logManager)
+	DB, err = gorm.Open(dialector, &gorm.Config{
+		Logger:               gormLogger,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		log.Fatal("数据库初始化失败:", err)
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		log.Fatal("Failed to get sql.DB:", err)
+	}
+	configureConnectionPool(sqlDB)
+	NewConnectionPoolMonitor(sqlDB, nil).StartMonitoring(5 * time.Minute)
+
+	useGormLoggerPlugin(gormLogger)
+	log.Println("数据库不可用，以降级模式初始化连接")
+}
+
 // InitDBWithLogger 使用指定的StorageManager初始化数据库连接（向后兼容）
 func InitDBWithLogger(storageManager *Storage.StorageManager) {
-	initDB()
+	initDB(getGormLogger())
 
 	// 如果提供了StorageManager，则包装数据库连接以添加SQL日志
 	if storageManager != nil {
@@ -531,6 +599,31 @@ func InitDBWithLogger(storageManager *Storage.StorageManager) {
 	}
 }
 
+// gormLoggerFor 返回日志管理器对应的GORM日志记录器
+// 优先使用日志管理器提供的适配器，否则使用 GormLogManagerWrapper，未提供日志管理器时输出到标准输出
+func gormLoggerFor(logManager LogManagerInterface) logger.Interface {
+	if logManager == nil {
+		return getGormLogger()
+	}
+	if provider, ok := logManager.(GormLoggerProvider); ok {
+		if gormLogger := provider.GormLogger(); gormLogger != nil {
+			return gormLogger
+		}
+	}
+	return &GormLogManagerWrapper{logManager: logManager}
+}
+
+// useGormLoggerPlugin GORM日志记录器实现 gorm.Plugin 时注册到全局连接，用于按模型统计语句
+func useGormLoggerPlugin(gormLogger logger.Interface) {
+	plugin, ok := gormLogger.(gorm.Plugin)
+	if !ok || DB == nil {
+		return
+	}
+	if err := DB.Use(plugin); err != nil {
+		log.Printf("注册GORM日志插件失败: %v", err)
+	}
+}
+
 // getGormLogger 获取GORM日志配置
 // 功能说明：
 // 1. 根据环境配置设置日志级别
@@ -565,17 +658,32 @@ func getGormLogger() logger.Interface {
 // 2. 支持版本控制和回滚功能
 // 3. 记录迁移成功或失败的日志
 // 4. 包含所有核心业务模型和审计日志表
+// 5. 执行前预检，安全模式下拒绝破坏性变更，生产环境需要已审批的迁移计划
 func AutoMigrate() {
 	// 使用新的迁移系统
 	migrationManager := Migrations.NewMigrationManager(DB)
 
-	if err := migrationManager.RunMigrations(); err != nil {
+	if err := migrationManager.RunMigrationsWithPreflight(MigrationPreflightOptions()); err != nil {
 		log.Fatal("Failed to run migrations:", err)
 	}
 
 	log.Println("Database migrations completed successfully")
 }
 
+// MigrationPreflightOptions 根据配置生成迁移预检选项
+func MigrationPreflightOptions() Migrations.PreflightOptions {
+	cfg := Config.GetConfig()
+	return Migrations.PreflightOptions{
+		LargeTableRows:  cfg.Migration.LargeTableRows,
+		RowsPerSecond:   cfg.Migration.RowsPerSecond,
+		MaxLockTime:     cfg.Migration.MaxLockTime,
+		OnlineIndexes:   cfg.Migration.OnlineIndexes,
+		SafeMode:        cfg.Migration.SafeMode,
+		RequireApproval: cfg.Migration.ApprovalRequired(cfg.Server.Environment),
+		ApprovalTTL:     cfg.Migration.ApprovalTTL,
+	}
+}
+
 // GetDB 获取GORM数据库实例
 // 功能说明：
 // 1. 返回全局的GORM数据库实例
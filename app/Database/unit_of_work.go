@@ -0,0 +1,165 @@
+package Database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 事务重试的默认参数
+const (
+	defaultTransactionRetries    = 3
+	defaultTransactionRetryDelay = 20 * time.Millisecond
+	maxTransactionRetryDelay     = time.Second
+)
+
+// unitOfWorkKey 上下文中当前事务的键
+type unitOfWorkKey struct{}
+
+// unitOfWorkState 上下文中的当前事务
+type unitOfWorkState struct {
+	pool gorm.ConnPool // 开启事务的连接池，用于判断嵌套调用是否属于同一个数据库
+	tx   *gorm.DB
+}
+
+// UnitOfWork 事务工作单元
+// 功能说明：
+// 1. WithTransaction 在一个事务中执行多次写入，返回错误或 panic 时回滚
+// 2. 事务通过 ctx 传递，在事务中再次调用 WithTransaction 时使用保存点嵌套，内层失败只回滚到保存点
+// 3. 最外层事务遇到序列化失败、死锁或数据库锁定时整体重试，内层不重试
+//
+// 注意事项：
+// - fn 可能被执行多次，不能包含不可重复的副作用（如发送通知），这类操作应在 WithTransaction 返回后执行
+// - fn 中的查询需要使用传入的 tx，使用其他连接的查询不属于该事务
+type UnitOfWork struct {
+	db         *gorm.DB
+	maxRetries int
+	retryDelay time.Duration
+}
+
+// NewUnitOfWork 创建事务工作单元
+func NewUnitOfWork(db *gorm.DB) *UnitOfWork {
+	return &UnitOfWork{
+		db:         db,
+		maxRetries: defaultTransactionRetries,
+		retryDelay: defaultTransactionRetryDelay,
+	}
+}
+
+// SetRetry 设置序列化失败时的最大重试次数和初始重试间隔，间隔按次数翻倍；maxRetries 为0表示不重试
+func (u *UnitOfWork) SetRetry(maxRetries int, retryDelay time.Duration) {
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	u.maxRetries = maxRetries
+	u.retryDelay = retryDelay
+}
+
+// DB 返回 ctx 中当前事务的连接，不在事务中时返回普通连接
+func (u *UnitOfWork) DB(ctx context.Context) *gorm.DB {
+	if state := u.current(ctx); state != nil {
+		return state.tx
+	}
+	if ctx == nil {
+		return u.db
+	}
+	return u.db.WithContext(ctx)
+}
+
+// WithTransaction 在事务中执行 fn，ctx 中已有同一数据库的事务时以保存点嵌套执行
+func (u *UnitOfWork) WithTransaction(ctx context.Context, fn func(ctx context.Context, tx *gorm.DB) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if state := u.current(ctx); state != nil {
+		// gorm 在已开启的事务中调用 Transaction 时使用 SAVEPOINT，fn 返回错误时回滚到保存点
+		return state.tx.Transaction(func(tx *gorm.DB) error {
+			return fn(context.WithValue(ctx, unitOfWorkKey{}, &unitOfWorkState{pool: state.pool, tx: tx}), tx)
+		})
+	}
+
+	delay := u.retryDelay
+	for attempt := 0; ; attempt++ {
+		err := u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(context.WithValue(ctx, unitOfWorkKey{}, &unitOfWorkState{pool: u.db.ConnPool, tx: tx}), tx)
+		})
+		if err == nil || !IsSerializationFailure(err) {
+			return err
+		}
+		if attempt >= u.maxRetries {
+			if attempt == 0 {
+				return err
+			}
+			return fmt.Errorf("事务重试%d次后仍失败: %w", attempt, err)
+		}
+		log.Printf("事务序列化失败，%v后重试 (%d/%d): %v", delay, attempt+1, u.maxRetries, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxTransactionRetryDelay {
+			delay = maxTransactionRetryDelay
+		}
+	}
+}
+
+// current 返回 ctx 中属于同一数据库的当前事务
+func (u *UnitOfWork) current(ctx context.Context) *unitOfWorkState {
+	if ctx == nil {
+		return nil
+	}
+	state, ok := ctx.Value(unitOfWorkKey{}).(*unitOfWorkState)
+	if !ok || state.pool != u.db.ConnPool {
+		return nil
+	}
+	return state
+}
+
+// TxFromContext 返回 ctx 中当前事务的连接，不在事务中时返回 false
+func TxFromContext(ctx context.Context) (*gorm.DB, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	state, ok := ctx.Value(unitOfWorkKey{}).(*unitOfWorkState)
+	if !ok {
+		return nil, false
+	}
+	return state.tx, true
+}
+
+// serializationFailureMarkers 各数据库序列化失败、死锁和锁定错误的特征
+// PostgreSQL 40001/40P01，MySQL 1213（死锁）/1205（锁等待超时），SQLite SQLITE_BUSY/SQLITE_LOCKED
+var serializationFailureMarkers = []string{
+	"sqlstate 40001",
+	"sqlstate 40p01",
+	"could not serialize access",
+	"serialization failure",
+	"deadlock",
+	"error 1213",
+	"error 1205",
+	"lock wait timeout",
+	"database is locked",
+	"database table is locked",
+	"sqlite_busy",
+	"sqlite_locked",
+}
+
+// IsSerializationFailure 判断错误是否为可以重试整个事务的序列化失败、死锁或数据库锁定
+func IsSerializationFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	for _, marker := range serializationFailureMarkers {
+		if strings.Contains(message, marker) {
+			return true
+		}
+	}
+	return false
+}
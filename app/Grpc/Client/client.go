@@ -0,0 +1,172 @@
+package Client
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Grpc/Proto"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Client 内部gRPC接口的客户端
+// 功能说明：
+// 1. 使用客户端证书通过双向TLS连接服务端，请求走HTTP/2
+// 2. 上下文带截止时间时通过 grpc-timeout 传给服务端
+// 3. 调用失败返回 *Proto.Status，可用 Proto.CodeOf 取状态码
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// traceparentKey 上下文中要传播的traceparent
+type traceparentKey struct{}
+
+// WithTraceparent 设置调用时传给服务端的W3C traceparent
+func WithTraceparent(ctx context.Context, traceparent string) context.Context {
+	return context.WithValue(ctx, traceparentKey{}, traceparent)
+}
+
+// New 创建客户端，address 为 host:port，tlsConfig 必须包含客户端证书和服务端CA
+func New(address string, tlsConfig *tls.Config) *Client {
+	return &Client{
+		baseURL: "https://" + strings.TrimPrefix(address, "https://"),
+		httpClient: &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   tlsConfig,
+			ForceAttemptHTTP2: true,
+			IdleConnTimeout:   90 * time.Second,
+		}},
+	}
+}
+
+// NewFromFiles 从证书文件创建客户端，serverName 为空时使用 address 中的主机名校验服务端证书
+func NewFromFiles(address, certFile, keyFile, caFile, serverName string) (*Client, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("加载客户端证书失败: %v", err)
+	}
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("读取服务端CA失败: %v", err)
+	}
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("服务端CA文件中没有有效证书")
+	}
+	return New(address, &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      rootCAs,
+		ServerName:   serverName,
+		MinVersion:   tls.VersionTLS12,
+	}), nil
+}
+
+// Close 关闭空闲连接
+func (c *Client) Close() {
+	c.httpClient.CloseIdleConnections()
+}
+
+// Invoke 调用一元方法
+func (c *Client) Invoke(ctx context.Context, method string, req, resp Proto.Message) error {
+	body := &bytes.Buffer{}
+	if err := Proto.WriteFrame(body, req.Marshal()); err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+method, body)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/grpc")
+	httpReq.Header.Set("Te", "trailers")
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout := time.Until(deadline).Milliseconds()
+		if timeout <= 0 {
+			return Proto.Errorf(Proto.DeadlineExceeded, "调用超时")
+		}
+		httpReq.Header.Set("Grpc-Timeout", fmt.Sprintf("%dm", timeout))
+	}
+	if traceparent, ok := ctx.Value(traceparentKey{}).(string); ok && traceparent != "" {
+		httpReq.Header.Set("Traceparent", traceparent)
+	}
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return Proto.Errorf(Proto.DeadlineExceeded, "调用超时")
+		}
+		return Proto.Errorf(Proto.Unavailable, "连接服务端失败: %v", err)
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(httpResp.Body, 1024))
+		return Proto.Errorf(Proto.Unknown, "HTTP状态 %d: %s", httpResp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	// 读取完响应体后尾部才可用
+	payload, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return Proto.Errorf(Proto.Unavailable, "读取响应失败: %v", err)
+	}
+	if err := responseStatus(httpResp); err != nil {
+		return err
+	}
+	message, err := Proto.ReadFrame(bytes.NewReader(payload), 0)
+	if err != nil {
+		return Proto.Errorf(Proto.Internal, "无效的响应消息: %v", err)
+	}
+	if err := resp.Unmarshal(message); err != nil {
+		return Proto.Errorf(Proto.Internal, "解码响应失败: %v", err)
+	}
+	return nil
+}
+
+// responseStatus 从尾部（或只有头部的响应）读取调用状态
+func responseStatus(resp *http.Response) error {
+	value, message := resp.Trailer.Get("Grpc-Status"), resp.Trailer.Get("Grpc-Message")
+	if value == "" {
+		value, message = resp.Header.Get("Grpc-Status"), resp.Header.Get("Grpc-Message")
+	}
+	if value == "" {
+		return Proto.Errorf(Proto.Internal, "响应缺少 grpc-status")
+	}
+	code, err := Proto.ParseCode(value)
+	if err != nil {
+		return Proto.Errorf(Proto.Internal, "%v", err)
+	}
+	if code != Proto.OK {
+		return &Proto.Status{Code: code, Message: Proto.DecodeMessage(message)}
+	}
+	return nil
+}
+
+// PushMetrics 推送指标
+func (c *Client) PushMetrics(ctx context.Context, samples []Proto.Sample) (*Proto.PushMetricsResponse, error) {
+	resp := &Proto.PushMetricsResponse{}
+	if err := c.Invoke(ctx, Proto.MethodPushMetrics, &Proto.PushMetricsRequest{Samples: samples}, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ListAlerts 按游标分页查询告警
+func (c *Client) ListAlerts(ctx context.Context, req *Proto.ListAlertsRequest) (*Proto.ListAlertsResponse, error) {
+	resp := &Proto.ListAlertsResponse{}
+	if err := c.Invoke(ctx, Proto.MethodListAlerts, req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ReportSecurityEvent 上报安全事件
+func (c *Client) ReportSecurityEvent(ctx context.Context, req *Proto.ReportEventRequest) (*Proto.ReportEventResponse, error) {
+	resp := &Proto.ReportEventResponse{}
+	if err := c.Invoke(ctx, Proto.MethodReportEvent, req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,438 @@
+package Proto
+
+import (
+	"math"
+	"sort"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Message 可编解码的protobuf消息
+type Message interface {
+	Marshal() []byte
+	Unmarshal(data []byte) error
+}
+
+// Sample 指标采样
+type Sample struct {
+	Name            string
+	Value           float64
+	Labels          map[string]string
+	TimestampUnixMs int64
+}
+
+// PushMetricsRequest 指标推送请求
+type PushMetricsRequest struct {
+	Samples []Sample
+}
+
+// PushMetricsResponse 指标推送结果
+type PushMetricsResponse struct {
+	Accepted int32
+	Rejected int32
+	Errors   []string
+}
+
+// ListAlertsRequest 告警查询请求
+type ListAlertsRequest struct {
+	Status   string
+	Severity string
+	Limit    int32
+	Cursor   string
+}
+
+// Alert 告警
+type Alert struct {
+	ID               string
+	RuleID           string
+	Level            string
+	Message          string
+	Metric           string
+	Value            float64
+	Threshold        float64
+	Status           string
+	Count            int32
+	CreatedAtUnixMs  int64
+	ResolvedAtUnixMs int64
+	Fingerprint      string
+}
+
+// ListAlertsResponse 告警查询结果
+type ListAlertsResponse struct {
+	Alerts     []Alert
+	NextCursor string
+	HasMore    bool
+}
+
+// ReportEventRequest 安全事件上报请求
+type ReportEventRequest struct {
+	UserID     uint64
+	EventType  string
+	EventLevel string
+	IPAddress  string
+	UserAgent  string
+	Resource   string
+	Action     string
+	Details    string
+	RiskScore  float64
+	Blocked    bool
+}
+
+// ReportEventResponse 安全事件上报结果
+type ReportEventResponse struct {
+	Accepted bool
+}
+
+// 编码辅助函数，与proto3一致省略零值字段
+
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+func appendBytes(b []byte, num protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func appendDouble(b []byte, num protowire.Number, v float64) []byte {
+	if v == 0 && !math.Signbit(v) {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(v))
+}
+
+func appendBool(b []byte, num protowire.Number, v bool) []byte {
+	if !v {
+		return b
+	}
+	return appendVarint(b, num, 1)
+}
+
+// walk 依次访问protobuf消息的字段
+// 长度分隔字段传入 raw，varint 和定长字段传入 scalar
+func walk(data []byte, visit func(num protowire.Number, raw []byte, scalar uint64) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		var raw []byte
+		var scalar uint64
+		switch typ {
+		case protowire.VarintType:
+			scalar, n = protowire.ConsumeVarint(data)
+		case protowire.Fixed64Type:
+			scalar, n = protowire.ConsumeFixed64(data)
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(data)
+			scalar = uint64(v)
+		case protowire.BytesType:
+			raw, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		if err := visit(num, raw, scalar); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Marshal 编码采样，标签按键排序保证编码结果稳定
+func (m *Sample) Marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, m.Name)
+	b = appendDouble(b, 2, m.Value)
+	keys := make([]string, 0, len(m.Labels))
+	for key := range m.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		var entry []byte
+		entry = appendString(entry, 1, key)
+		entry = appendString(entry, 2, m.Labels[key])
+		b = appendBytes(b, 3, entry)
+	}
+	b = appendVarint(b, 4, uint64(m.TimestampUnixMs))
+	return b
+}
+
+// Unmarshal 解码采样
+func (m *Sample) Unmarshal(data []byte) error {
+	*m = Sample{}
+	return walk(data, func(num protowire.Number, raw []byte, scalar uint64) error {
+		switch num {
+		case 1:
+			m.Name = string(raw)
+		case 2:
+			m.Value = math.Float64frombits(scalar)
+		case 3:
+			var key, value string
+			err := walk(raw, func(num protowire.Number, raw []byte, _ uint64) error {
+				switch num {
+				case 1:
+					key = string(raw)
+				case 2:
+					value = string(raw)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if m.Labels == nil {
+				m.Labels = make(map[string]string)
+			}
+			m.Labels[key] = value
+		case 4:
+			m.TimestampUnixMs = int64(scalar)
+		}
+		return nil
+	})
+}
+
+// Marshal 编码指标推送请求
+func (m *PushMetricsRequest) Marshal() []byte {
+	var b []byte
+	for i := range m.Samples {
+		b = appendBytes(b, 1, m.Samples[i].Marshal())
+	}
+	return b
+}
+
+// Unmarshal 解码指标推送请求
+func (m *PushMetricsRequest) Unmarshal(data []byte) error {
+	*m = PushMetricsRequest{}
+	return walk(data, func(num protowire.Number, raw []byte, _ uint64) error {
+		if num != 1 {
+			return nil
+		}
+		var sample Sample
+		if err := sample.Unmarshal(raw); err != nil {
+			return err
+		}
+		m.Samples = append(m.Samples, sample)
+		return nil
+	})
+}
+
+// Marshal 编码指标推送结果
+func (m *PushMetricsResponse) Marshal() []byte {
+	var b []byte
+	b = appendVarint(b, 1, uint64(m.Accepted))
+	b = appendVarint(b, 2, uint64(m.Rejected))
+	for _, e := range m.Errors {
+		b = appendBytes(b, 3, []byte(e))
+	}
+	return b
+}
+
+// Unmarshal 解码指标推送结果
+func (m *PushMetricsResponse) Unmarshal(data []byte) error {
+	*m = PushMetricsResponse{}
+	return walk(data, func(num protowire.Number, raw []byte, scalar uint64) error {
+		switch num {
+		case 1:
+			m.Accepted = int32(scalar)
+		case 2:
+			m.Rejected = int32(scalar)
+		case 3:
+			m.Errors = append(m.Errors, string(raw))
+		}
+		return nil
+	})
+}
+
+// Marshal 编码告警查询请求
+func (m *ListAlertsRequest) Marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, m.Status)
+	b = appendString(b, 2, m.Severity)
+	b = appendVarint(b, 3, uint64(m.Limit))
+	b = appendString(b, 4, m.Cursor)
+	return b
+}
+
+// Unmarshal 解码告警查询请求
+func (m *ListAlertsRequest) Unmarshal(data []byte) error {
+	*m = ListAlertsRequest{}
+	return walk(data, func(num protowire.Number, raw []byte, scalar uint64) error {
+		switch num {
+		case 1:
+			m.Status = string(raw)
+		case 2:
+			m.Severity = string(raw)
+		case 3:
+			m.Limit = int32(scalar)
+		case 4:
+			m.Cursor = string(raw)
+		}
+		return nil
+	})
+}
+
+// Marshal 编码告警
+func (m *Alert) Marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, m.ID)
+	b = appendString(b, 2, m.RuleID)
+	b = appendString(b, 3, m.Level)
+	b = appendString(b, 4, m.Message)
+	b = appendString(b, 5, m.Metric)
+	b = appendDouble(b, 6, m.Value)
+	b = appendDouble(b, 7, m.Threshold)
+	b = appendString(b, 8, m.Status)
+	b = appendVarint(b, 9, uint64(m.Count))
+	b = appendVarint(b, 10, uint64(m.CreatedAtUnixMs))
+	b = appendVarint(b, 11, uint64(m.ResolvedAtUnixMs))
+	b = appendString(b, 12, m.Fingerprint)
+	return b
+}
+
+// Unmarshal 解码告警
+func (m *Alert) Unmarshal(data []byte) error {
+	*m = Alert{}
+	return walk(data, func(num protowire.Number, raw []byte, scalar uint64) error {
+		switch num {
+		case 1:
+			m.ID = string(raw)
+		case 2:
+			m.RuleID = string(raw)
+		case 3:
+			m.Level = string(raw)
+		case 4:
+			m.Message = string(raw)
+		case 5:
+			m.Metric = string(raw)
+		case 6:
+			m.Value = math.Float64frombits(scalar)
+		case 7:
+			m.Threshold = math.Float64frombits(scalar)
+		case 8:
+			m.Status = string(raw)
+		case 9:
+			m.Count = int32(scalar)
+		case 10:
+			m.CreatedAtUnixMs = int64(scalar)
+		case 11:
+			m.ResolvedAtUnixMs = int64(scalar)
+		case 12:
+			m.Fingerprint = string(raw)
+		}
+		return nil
+	})
+}
+
+// Marshal 编码告警查询结果
+func (m *ListAlertsResponse) Marshal() []byte {
+	var b []byte
+	for i := range m.Alerts {
+		b = appendBytes(b, 1, m.Alerts[i].Marshal())
+	}
+	b = appendString(b, 2, m.NextCursor)
+	b = appendBool(b, 3, m.HasMore)
+	return b
+}
+
+// Unmarshal 解码告警查询结果
+func (m *ListAlertsResponse) Unmarshal(data []byte) error {
+	*m = ListAlertsResponse{}
+	return walk(data, func(num protowire.Number, raw []byte, scalar uint64) error {
+		switch num {
+		case 1:
+			var alert Alert
+			if err := alert.Unmarshal(raw); err != nil {
+				return err
+			}
+			m.Alerts = append(m.Alerts, alert)
+		case 2:
+			m.NextCursor = string(raw)
+		case 3:
+			m.HasMore = scalar != 0
+		}
+		return nil
+	})
+}
+
+// Marshal 编码安全事件上报请求
+func (m *ReportEventRequest) Marshal() []byte {
+	var b []byte
+	b = appendVarint(b, 1, m.UserID)
+	b = appendString(b, 2, m.EventType)
+	b = appendString(b, 3, m.EventLevel)
+	b = appendString(b, 4, m.IPAddress)
+	b = appendString(b, 5, m.UserAgent)
+	b = appendString(b, 6, m.Resource)
+	b = appendString(b, 7, m.Action)
+	b = appendString(b, 8, m.Details)
+	b = appendDouble(b, 9, m.RiskScore)
+	b = appendBool(b, 10, m.Blocked)
+	return b
+}
+
+// Unmarshal 解码安全事件上报请求
+func (m *ReportEventRequest) Unmarshal(data []byte) error {
+	*m = ReportEventRequest{}
+	return walk(data, func(num protowire.Number, raw []byte, scalar uint64) error {
+		switch num {
+		case 1:
+			m.UserID = scalar
+		case 2:
+			m.EventType = string(raw)
+		case 3:
+			m.EventLevel = string(raw)
+		case 4:
+			m.IPAddress = string(raw)
+		case 5:
+			m.UserAgent = string(raw)
+		case 6:
+			m.Resource = string(raw)
+		case 7:
+			m.Action = string(raw)
+		case 8:
+			m.Details = string(raw)
+		case 9:
+			m.RiskScore = math.Float64frombits(scalar)
+		case 10:
+			m.Blocked = scalar != 0
+		}
+		return nil
+	})
+}
+
+// Marshal 编码安全事件上报结果
+func (m *ReportEventResponse) Marshal() []byte {
+	return appendBool(nil, 1, m.Accepted)
+}
+
+// Unmarshal 解码安全事件上报结果
+func (m *ReportEventResponse) Unmarshal(data []byte) error {
+	*m = ReportEventResponse{}
+	return walk(data, func(num protowire.Number, _ []byte, scalar uint64) error {
+		if num == 1 {
+			m.Accepted = scalar != 0
+		}
+		return nil
+	})
+}
@@ -0,0 +1,124 @@
+package Proto
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+)
+
+// Code gRPC状态码
+type Code uint32
+
+// 使用到的gRPC状态码，取值与 google.golang.org/grpc/codes 一致
+const (
+	OK                Code = 0
+	Canceled          Code = 1
+	Unknown           Code = 2
+	InvalidArgument   Code = 3
+	DeadlineExceeded  Code = 4
+	NotFound          Code = 5
+	PermissionDenied  Code = 7
+	ResourceExhausted Code = 8
+	Unimplemented     Code = 12
+	Internal          Code = 13
+	Unavailable       Code = 14
+	Unauthenticated   Code = 16
+)
+
+// 完整方法名
+const (
+	MethodPushMetrics = "/cloudplatform.internal.v1.MetricsIngestService/PushMetrics"
+	MethodListAlerts  = "/cloudplatform.internal.v1.AlertQueryService/ListAlerts"
+	MethodReportEvent = "/cloudplatform.internal.v1.SecurityEventService/ReportEvent"
+)
+
+// Status gRPC调用失败时的状态，实现 error 接口
+type Status struct {
+	Code    Code
+	Message string
+}
+
+func (s *Status) Error() string {
+	return fmt.Sprintf("rpc error: code = %d desc = %s", s.Code, s.Message)
+}
+
+// Errorf 创建指定状态码的错误
+func Errorf(code Code, format string, args ...interface{}) error {
+	return &Status{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// StatusOf 获取错误的状态，非 Status 错误视为 Unknown
+func StatusOf(err error) *Status {
+	if err == nil {
+		return &Status{Code: OK}
+	}
+	var status *Status
+	if errors.As(err, &status) {
+		return status
+	}
+	return &Status{Code: Unknown, Message: err.Error()}
+}
+
+// CodeOf 获取错误的状态码
+func CodeOf(err error) Code {
+	return StatusOf(err).Code
+}
+
+// EncodeMessage 对 grpc-message 做百分号编码
+func EncodeMessage(message string) string {
+	return url.PathEscape(message)
+}
+
+// DecodeMessage 解码 grpc-message
+func DecodeMessage(message string) string {
+	if decoded, err := url.PathUnescape(message); err == nil {
+		return decoded
+	}
+	return message
+}
+
+// ParseCode 解析 grpc-status
+func ParseCode(value string) (Code, error) {
+	code, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return Unknown, fmt.Errorf("无效的 grpc-status: %q", value)
+	}
+	return Code(code), nil
+}
+
+// WriteFrame 写入一个长度前缀消息：1字节压缩标志 + 4字节大端长度 + 消息体，不压缩
+func WriteFrame(w io.Writer, payload []byte) error {
+	header := make([]byte, 5)
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// ReadFrame 读取一个长度前缀消息，超过 maxSize 返回 ResourceExhausted，不支持压缩消息
+func ReadFrame(r io.Reader, maxSize int) ([]byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.EOF {
+			return nil, Errorf(InvalidArgument, "请求缺少消息体")
+		}
+		return nil, Errorf(InvalidArgument, "读取消息头失败: %v", err)
+	}
+	if header[0] != 0 {
+		return nil, Errorf(Unimplemented, "不支持压缩消息")
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if maxSize > 0 && int64(size) > int64(maxSize) {
+		return nil, Errorf(ResourceExhausted, "消息大小 %d 超过上限 %d", size, maxSize)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, Errorf(InvalidArgument, "读取消息体失败: %v", err)
+	}
+	return payload, nil
+}
@@ -0,0 +1,143 @@
+package Grpc
+
+import (
+	"cloud-platform-api/app/Grpc/Proto"
+	"cloud-platform-api/app/Services"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// headerKey 上下文中请求头的键
+type headerKey struct{}
+
+// responseHeaderKey 上下文中响应头的键，拦截器可在响应写出前设置响应头
+type responseHeaderKey struct{}
+
+// traceKey 上下文中追踪上下文（traceparent）的键
+type traceKey struct{}
+
+// TraceparentFromContext 获取当前调用的W3C traceparent
+func TraceparentFromContext(ctx context.Context) string {
+	traceparent, _ := ctx.Value(traceKey{}).(string)
+	return traceparent
+}
+
+// LoggingInterceptor 记录每次调用的方法、调用方、状态码和耗时
+func LoggingInterceptor() UnaryInterceptor {
+	return func(ctx context.Context, info *UnaryInfo, payload []byte, next UnaryHandler) ([]byte, error) {
+		start := time.Now()
+		response, err := next(ctx, payload)
+		status := Proto.StatusOf(err)
+		if err != nil {
+			log.Printf("gRPC调用失败: method=%s, peer=%s, code=%d, duration=%v, error=%s",
+				info.FullMethod, info.Peer, status.Code, time.Since(start), status.Message)
+		} else {
+			log.Printf("gRPC调用: method=%s, peer=%s, duration=%v", info.FullMethod, info.Peer, time.Since(start))
+		}
+		return response, err
+	}
+}
+
+// MethodStats 单个方法的调用统计
+type MethodStats struct {
+	Requests      int64         `json:"requests"`
+	Errors        int64         `json:"errors"`
+	TotalDuration time.Duration `json:"total_duration"`
+}
+
+// MetricsInterceptor 统计调用次数、错误次数和耗时
+// 功能说明：
+// 1. 按方法累计，通过 Stats 查询
+// 2. core 不为空时同步到监控指标 grpc_requests_total、grpc_errors_total 和 grpc_last_duration_ms
+type MetricsInterceptor struct {
+	core  *Services.MonitoringCore
+	mu    sync.Mutex
+	stats map[string]*MethodStats
+}
+
+// NewMetricsInterceptor 创建调用统计拦截器
+func NewMetricsInterceptor(core *Services.MonitoringCore) *MetricsInterceptor {
+	return &MetricsInterceptor{core: core, stats: make(map[string]*MethodStats)}
+}
+
+// Interceptor 返回拦截器函数
+func (m *MetricsInterceptor) Interceptor() UnaryInterceptor {
+	return func(ctx context.Context, info *UnaryInfo, payload []byte, next UnaryHandler) ([]byte, error) {
+		start := time.Now()
+		response, err := next(ctx, payload)
+		duration := time.Since(start)
+
+		m.mu.Lock()
+		stats, ok := m.stats[info.FullMethod]
+		if !ok {
+			stats = &MethodStats{}
+			m.stats[info.FullMethod] = stats
+		}
+		stats.Requests++
+		if err != nil {
+			stats.Errors++
+		}
+		stats.TotalDuration += duration
+		var requests, errors int64
+		for _, s := range m.stats {
+			requests += s.Requests
+			errors += s.Errors
+		}
+		m.mu.Unlock()
+
+		if m.core != nil {
+			m.core.Observe("grpc_requests_total", float64(requests))
+			m.core.Observe("grpc_errors_total", float64(errors))
+			m.core.Observe("grpc_last_duration_ms", float64(duration.Microseconds())/1000)
+		}
+		return response, err
+	}
+}
+
+// Stats 各方法的调用统计
+func (m *MetricsInterceptor) Stats() map[string]MethodStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := make(map[string]MethodStats, len(m.stats))
+	for method, stats := range m.stats {
+		result[method] = *stats
+	}
+	return result
+}
+
+// traceparentPattern W3C traceparent：版本-trace-id-parent-id-flags
+var traceparentPattern = regexp.MustCompile(`^[0-9a-f]{2}-([0-9a-f]{32})-[0-9a-f]{16}-([0-9a-f]{2})$`)
+
+// TracingInterceptor 传播W3C追踪上下文
+// 功能说明：
+// 1. 请求带有效 traceparent 时沿用其 trace-id 和 flags，否则生成新的 trace-id
+// 2. 为本次调用生成新的 span-id，写入上下文并通过响应头 traceparent 返回
+func TracingInterceptor() UnaryInterceptor {
+	return func(ctx context.Context, info *UnaryInfo, payload []byte, next UnaryHandler) ([]byte, error) {
+		traceID, flags := randomHex(16), "01"
+		if header, ok := ctx.Value(headerKey{}).(http.Header); ok {
+			if match := traceparentPattern.FindStringSubmatch(header.Get("Traceparent")); match != nil && match[1] != "00000000000000000000000000000000" {
+				traceID, flags = match[1], match[2]
+			}
+		}
+		traceparent := "00-" + traceID + "-" + randomHex(8) + "-" + flags
+		if header, ok := ctx.Value(responseHeaderKey{}).(http.Header); ok {
+			header.Set("Traceparent", traceparent)
+		}
+		ctx = context.WithValue(ctx, traceKey{}, traceparent)
+		return next(ctx, payload)
+	}
+}
+
+// randomHex 生成n字节的随机十六进制字符串
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
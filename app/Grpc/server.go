@@ -0,0 +1,308 @@
+package Grpc
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Grpc/Proto"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UnaryInfo 一次调用的信息
+type UnaryInfo struct {
+	FullMethod string // 完整方法名，如 /cloudplatform.internal.v1.AlertQueryService/ListAlerts
+	Peer       string // 客户端证书的通用名称
+}
+
+// UnaryHandler 处理请求消息，返回编码后的响应消息
+type UnaryHandler func(ctx context.Context, payload []byte) ([]byte, error)
+
+// UnaryInterceptor 拦截器，调用 next 继续处理
+type UnaryInterceptor func(ctx context.Context, info *UnaryInfo, payload []byte, next UnaryHandler) ([]byte, error)
+
+// Server 内部gRPC服务器
+// 功能说明：
+// 1. 在HTTP/2上实现gRPC一元调用：长度前缀消息、grpc-status/grpc-message 尾部和 grpc-timeout 超时
+// 2. 只接受双向TLS连接，客户端证书的通用名称作为调用方身份，可按白名单限制
+// 3. 方法通过 Register 注册，拦截器按 Use 的顺序执行
+// 4. 不依赖 google.golang.org/grpc，与标准gRPC客户端兼容（不支持压缩和流式调用）
+type Server struct {
+	config       Config.GrpcConfig
+	allowed      map[string]bool
+	mu           sync.RWMutex
+	handlers     map[string]UnaryHandler
+	interceptors []UnaryInterceptor
+	httpServer   *http.Server
+}
+
+// peerKey 上下文中调用方身份的键
+type peerKey struct{}
+
+// PeerFromContext 获取调用方身份
+func PeerFromContext(ctx context.Context) string {
+	peer, _ := ctx.Value(peerKey{}).(string)
+	return peer
+}
+
+// NewServer 创建gRPC服务器
+func NewServer(config Config.GrpcConfig) *Server {
+	if config.MaxMessageSize <= 0 {
+		config.MaxMessageSize = 4 << 20
+	}
+	if config.RequestTimeout <= 0 {
+		config.RequestTimeout = 30 * time.Second
+	}
+	allowed := make(map[string]bool)
+	for _, name := range config.AllowedClientNames() {
+		allowed[name] = true
+	}
+	return &Server{
+		config:   config,
+		allowed:  allowed,
+		handlers: make(map[string]UnaryHandler),
+	}
+}
+
+// Register 注册方法
+func (s *Server) Register(fullMethod string, handler UnaryHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[fullMethod] = handler
+}
+
+// Use 添加拦截器
+func (s *Server) Use(interceptors ...UnaryInterceptor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.interceptors = append(s.interceptors, interceptors...)
+}
+
+// Methods 已注册的方法
+func (s *Server) Methods() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	methods := make([]string, 0, len(s.handlers))
+	for method := range s.handlers {
+		methods = append(methods, method)
+	}
+	return methods
+}
+
+// ServeHTTP 处理gRPC请求
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.ProtoMajor != 2 {
+		http.Error(w, "gRPC需要HTTP/2", http.StatusHTTPVersionNotSupported)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "gRPC只支持POST请求", http.StatusMethodNotAllowed)
+		return
+	}
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		http.Error(w, "不支持的Content-Type", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+
+	peer, err := s.authenticate(r)
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+
+	s.mu.RLock()
+	handler, ok := s.handlers[r.URL.Path]
+	interceptors := s.interceptors
+	s.mu.RUnlock()
+	if !ok {
+		writeStatus(w, Proto.Errorf(Proto.Unimplemented, "未知方法: %s", r.URL.Path))
+		return
+	}
+
+	timeout := s.config.RequestTimeout
+	if value := r.Header.Get("Grpc-Timeout"); value != "" {
+		parsed, err := parseTimeout(value)
+		if err != nil {
+			writeStatus(w, Proto.Errorf(Proto.InvalidArgument, "%v", err))
+			return
+		}
+		timeout = parsed
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	ctx = context.WithValue(ctx, peerKey{}, peer)
+	ctx = context.WithValue(ctx, headerKey{}, r.Header)
+	ctx = context.WithValue(ctx, responseHeaderKey{}, w.Header())
+
+	payload, err := Proto.ReadFrame(r.Body, s.config.MaxMessageSize)
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+
+	info := &UnaryInfo{FullMethod: r.URL.Path, Peer: peer}
+	response, err := chain(interceptors, info, handler)(ctx, payload)
+	if err == nil && ctx.Err() == context.DeadlineExceeded {
+		err = Proto.Errorf(Proto.DeadlineExceeded, "调用超时")
+	}
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if err := Proto.WriteFrame(w, response); err != nil {
+		log.Printf("gRPC写入响应失败: method=%s, error=%v", r.URL.Path, err)
+		return
+	}
+	writeStatus(w, nil)
+}
+
+// authenticate 校验客户端证书，返回调用方身份
+func (s *Server) authenticate(r *http.Request) (string, error) {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return "", Proto.Errorf(Proto.Unauthenticated, "缺少客户端证书")
+	}
+	cert := r.TLS.PeerCertificates[0]
+	peer := cert.Subject.CommonName
+	if len(s.allowed) == 0 || s.allowed[peer] {
+		return peer, nil
+	}
+	for _, name := range cert.DNSNames {
+		if s.allowed[name] {
+			return name, nil
+		}
+	}
+	return "", Proto.Errorf(Proto.PermissionDenied, "客户端 %s 无权访问", peer)
+}
+
+// chain 按顺序组合拦截器
+func chain(interceptors []UnaryInterceptor, info *UnaryInfo, handler UnaryHandler) UnaryHandler {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], handler
+		handler = func(ctx context.Context, payload []byte) ([]byte, error) {
+			return interceptor(ctx, info, payload, next)
+		}
+	}
+	return handler
+}
+
+// writeStatus 写入 grpc-status 和 grpc-message 尾部
+func writeStatus(w http.ResponseWriter, err error) {
+	status := Proto.StatusOf(err)
+	w.Header().Set("Grpc-Status", strconv.Itoa(int(status.Code)))
+	if status.Message != "" {
+		w.Header().Set("Grpc-Message", Proto.EncodeMessage(status.Message))
+	}
+}
+
+// parseTimeout 解析 grpc-timeout，格式为不超过8位的整数加单位（H、M、S、m、u、n）
+func parseTimeout(value string) (time.Duration, error) {
+	if len(value) < 2 || len(value) > 9 {
+		return 0, fmt.Errorf("无效的 grpc-timeout: %q", value)
+	}
+	amount, err := strconv.ParseInt(value[:len(value)-1], 10, 64)
+	if err != nil || amount < 0 {
+		return 0, fmt.Errorf("无效的 grpc-timeout: %q", value)
+	}
+	units := map[byte]time.Duration{
+		'H': time.Hour, 'M': time.Minute, 'S': time.Second,
+		'm': time.Millisecond, 'u': time.Microsecond, 'n': time.Nanosecond,
+	}
+	unit, ok := units[value[len(value)-1]]
+	if !ok {
+		return 0, fmt.Errorf("无效的 grpc-timeout 单位: %q", value)
+	}
+	return time.Duration(amount) * unit, nil
+}
+
+// TLSConfig 按配置加载服务端证书和客户端CA，要求并校验客户端证书
+func (s *Server) TLSConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(s.config.CertFile, s.config.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("加载gRPC服务端证书失败: %v", err)
+	}
+	caPEM, err := os.ReadFile(s.config.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("读取gRPC客户端CA失败: %v", err)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("gRPC客户端CA文件中没有有效证书")
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2"},
+	}, nil
+}
+
+// Start 监听配置的地址并在后台提供服务
+func (s *Server) Start() error {
+	tlsConfig, err := s.TLSConfig()
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", s.config.Address)
+	if err != nil {
+		return fmt.Errorf("gRPC监听 %s 失败: %v", s.config.Address, err)
+	}
+
+	s.mu.Lock()
+	s.httpServer = &http.Server{Handler: s, TLSConfig: tlsConfig, ReadHeaderTimeout: 10 * time.Second}
+	httpServer := s.httpServer
+	s.mu.Unlock()
+
+	go func() {
+		log.Printf("gRPC server starting on %s", listener.Addr())
+		if err := httpServer.ServeTLS(listener, "", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("gRPC server error: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Stop 优雅关闭，等待进行中的调用完成
+func (s *Server) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	httpServer := s.httpServer
+	s.httpServer = nil
+	s.mu.Unlock()
+	if httpServer == nil {
+		return nil
+	}
+	return httpServer.Shutdown(ctx)
+}
+
+var (
+	defaultServer   *Server
+	defaultServerMu sync.RWMutex
+)
+
+// SetDefaultServer 设置全局gRPC服务器，应用关闭时停止
+func SetDefaultServer(server *Server) {
+	defaultServerMu.Lock()
+	defer defaultServerMu.Unlock()
+	defaultServer = server
+}
+
+// DefaultServer 获取全局gRPC服务器，未启用时为 nil
+func DefaultServer() *Server {
+	defaultServerMu.RLock()
+	defer defaultServerMu.RUnlock()
+	return defaultServer
+}
@@ -0,0 +1,169 @@
+package Grpc
+
+import (
+	"cloud-platform-api/app/Grpc/Proto"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Utils"
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+// 告警查询每页数量，与REST列表接口一致
+const (
+	defaultAlertPageSize = 20
+	maxAlertPageSize     = 100
+)
+
+// securityEventLevels 可上报的安全事件级别
+var securityEventLevels = map[string]bool{"low": true, "medium": true, "high": true, "critical": true}
+
+// InternalServices 内部gRPC接口的实现
+// 功能说明：
+// 1. PushMetrics 调用指标推送服务，来源为 grpc:<客户端名称>，共用推送限额和校验规则
+// 2. ListAlerts 按游标分页查询告警，游标与REST接口的 meta.cursor 通用
+// 3. ReportEvent 记录安全事件，触发与REST上报相同的通知和自动响应
+// 对应服务未启用时返回 Unavailable
+type InternalServices struct {
+	ingest     *Services.MetricIngestService
+	monitoring *Services.OptimizedMonitoringService
+	security   *Services.SecurityService
+}
+
+// NewInternalServices 创建内部接口实现，参数可以为 nil
+func NewInternalServices(ingest *Services.MetricIngestService, monitoring *Services.OptimizedMonitoringService, security *Services.SecurityService) *InternalServices {
+	return &InternalServices{ingest: ingest, monitoring: monitoring, security: security}
+}
+
+// Register 注册全部方法
+func (s *InternalServices) Register(server *Server) {
+	server.Register(Proto.MethodPushMetrics, s.pushMetrics)
+	server.Register(Proto.MethodListAlerts, s.listAlerts)
+	server.Register(Proto.MethodReportEvent, s.reportEvent)
+}
+
+// pushMetrics 推送指标
+func (s *InternalServices) pushMetrics(ctx context.Context, payload []byte) ([]byte, error) {
+	if s.ingest == nil {
+		return nil, Proto.Errorf(Proto.Unavailable, "指标推送未启用")
+	}
+	var req Proto.PushMetricsRequest
+	if err := req.Unmarshal(payload); err != nil {
+		return nil, Proto.Errorf(Proto.InvalidArgument, "无效的请求消息: %v", err)
+	}
+
+	samples := make([]Services.IngestSample, 0, len(req.Samples))
+	for _, sample := range req.Samples {
+		ingestSample := Services.IngestSample{Name: sample.Name, Value: sample.Value, Labels: sample.Labels}
+		if sample.TimestampUnixMs != 0 {
+			ingestSample.Timestamp = time.UnixMilli(sample.TimestampUnixMs)
+		}
+		samples = append(samples, ingestSample)
+	}
+
+	result, err := s.ingest.Ingest("grpc:"+PeerFromContext(ctx), samples)
+	if err != nil {
+		var quotaErr *Services.MetricIngestQuotaError
+		switch {
+		case errors.As(err, &quotaErr):
+			return nil, Proto.Errorf(Proto.ResourceExhausted, "%v", err)
+		case errors.Is(err, Services.ErrIngestBatchTooLarge):
+			return nil, Proto.Errorf(Proto.InvalidArgument, "%v", err)
+		default:
+			return nil, Proto.Errorf(Proto.Internal, "%v", err)
+		}
+	}
+	response := &Proto.PushMetricsResponse{
+		Accepted: int32(result.Accepted),
+		Rejected: int32(result.Rejected),
+		Errors:   result.Errors,
+	}
+	return response.Marshal(), nil
+}
+
+// listAlerts 查询告警
+func (s *InternalServices) listAlerts(ctx context.Context, payload []byte) ([]byte, error) {
+	if s.monitoring == nil {
+		return nil, Proto.Errorf(Proto.Unavailable, "监控服务未启用")
+	}
+	var req Proto.ListAlertsRequest
+	if err := req.Unmarshal(payload); err != nil {
+		return nil, Proto.Errorf(Proto.InvalidArgument, "无效的请求消息: %v", err)
+	}
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = defaultAlertPageSize
+	}
+	limit = min(limit, maxAlertPageSize)
+
+	alerts, meta, err := s.monitoring.ListAlerts(req.Status, req.Severity, nil, Utils.PageRequest{
+		Mode:   Utils.PageModeCursor,
+		Limit:  limit,
+		Cursor: req.Cursor,
+	}, nil)
+	if err != nil {
+		if errors.Is(err, Utils.ErrInvalidCursor) {
+			return nil, Proto.Errorf(Proto.InvalidArgument, "%v", err)
+		}
+		return nil, Proto.Errorf(Proto.Internal, "%v", err)
+	}
+
+	response := &Proto.ListAlertsResponse{NextCursor: meta.Cursor, HasMore: meta.HasMore}
+	for _, item := range alerts {
+		alert, ok := item.(*Services.Alert)
+		if !ok {
+			continue
+		}
+		message := Proto.Alert{
+			ID:              alert.ID,
+			RuleID:          alert.RuleID,
+			Level:           string(alert.Level),
+			Message:         alert.Message,
+			Metric:          alert.Metric,
+			Value:           alert.Value,
+			Threshold:       alert.Threshold,
+			Status:          alert.Status,
+			Count:           int32(alert.Count),
+			CreatedAtUnixMs: alert.CreatedAt.UnixMilli(),
+			Fingerprint:     alert.Fingerprint,
+		}
+		if alert.ResolvedAt != nil {
+			message.ResolvedAtUnixMs = alert.ResolvedAt.UnixMilli()
+		}
+		response.Alerts = append(response.Alerts, message)
+	}
+	return response.Marshal(), nil
+}
+
+// reportEvent 上报安全事件
+func (s *InternalServices) reportEvent(ctx context.Context, payload []byte) ([]byte, error) {
+	if s.security == nil {
+		return nil, Proto.Errorf(Proto.Unavailable, "安全服务未启用")
+	}
+	var req Proto.ReportEventRequest
+	if err := req.Unmarshal(payload); err != nil {
+		return nil, Proto.Errorf(Proto.InvalidArgument, "无效的请求消息: %v", err)
+	}
+	if req.EventType == "" {
+		return nil, Proto.Errorf(Proto.InvalidArgument, "事件类型不能为空")
+	}
+	if !securityEventLevels[req.EventLevel] {
+		return nil, Proto.Errorf(Proto.InvalidArgument, "无效的事件级别: %s", req.EventLevel)
+	}
+	if math.IsNaN(req.RiskScore) || req.RiskScore < 0 || req.RiskScore > 100 {
+		return nil, Proto.Errorf(Proto.InvalidArgument, "风险评分必须在0到100之间")
+	}
+
+	userAgent := req.UserAgent
+	if userAgent == "" {
+		userAgent = "grpc:" + PeerFromContext(ctx)
+	}
+	err := s.security.RecordSecurityEvent(ctx, uint(req.UserID), req.EventType, req.EventLevel, req.IPAddress, userAgent,
+		req.Resource, req.Action, req.Details, req.RiskScore, 0, req.Blocked, false, "", "")
+	if err != nil {
+		return nil, Proto.Errorf(Proto.Internal, "记录安全事件失败: %v", err)
+	}
+	response := &Proto.ReportEventResponse{Accepted: true}
+	return response.Marshal(), nil
+}
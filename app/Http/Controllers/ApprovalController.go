@@ -0,0 +1,201 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Utils"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ApprovalController 敏感操作审批控制器
+type ApprovalController struct {
+	Controller
+	approvalService *Services.ApprovalService
+}
+
+// NewApprovalController 创建敏感操作审批控制器
+func NewApprovalController(service *Services.ApprovalService) *ApprovalController {
+	return &ApprovalController{approvalService: service}
+}
+
+// ReviewApprovalRequest 审批请求
+type ReviewApprovalRequest struct {
+	Comment string `json:"comment" binding:"max=500"` // 审批意见，写入审计日志
+}
+
+// ApprovalQuerySpec 审批记录列表可筛选、排序和返回的字段
+var ApprovalQuerySpec = Utils.QuerySpec{
+	Fields: map[string]Utils.QueryField{
+		"id":           {Column: "id", Type: Utils.QueryInt, Filter: true, Sort: true},
+		"action":       {Column: "action", Type: Utils.QueryString, Filter: true, Sort: true},
+		"method":       {Column: "method", Type: Utils.QueryString, Filter: true},
+		"path":         {Column: "path", Type: Utils.QueryString, Filter: true},
+		"payload":      {Column: "payload", Type: Utils.QueryString},
+		"reason":       {Column: "reason", Type: Utils.QueryString, Filter: true},
+		"status":       {Column: "status", Type: Utils.QueryString, Filter: true, Sort: true},
+		"requested_by": {Column: "requested_by", Type: Utils.QueryInt, Filter: true},
+		"requester":    {Column: "requester_name", Type: Utils.QueryString, Filter: true},
+		"ip_address":   {Column: "ip_address", Type: Utils.QueryString, Filter: true},
+		"reviewed_by":  {Column: "reviewed_by", Type: Utils.QueryInt, Filter: true},
+		"reviewer":     {Column: "reviewer_name", Type: Utils.QueryString, Filter: true},
+		"reviewed_at":  {Column: "reviewed_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+		"expires_at":   {Column: "expires_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+		"executed_at":  {Column: "executed_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+		"created_at":   {Column: "created_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+	},
+	DefaultSort: "-id",
+}
+
+// GetApprovals 获取审批记录列表
+// @Summary 获取审批记录列表
+// @Description 分页查询敏感操作审批记录，支持 filter[field][op]=value 筛选和排序，如 filter[status][eq]=pending 查询待审批的操作（仅管理员）
+// @Tags 操作审批
+// @Produce json
+// @Security ApiKeyAuth
+// @Param filter[status][eq] query string false "按状态筛选：pending、approved、rejected、expired、executed"
+// @Param sort query string false "排序字段，逗号分隔，前缀-表示降序" default(-id)
+// @Param page query int false "页码" default(1)
+// @Param limit query int false "每页数量" default(20)
+// @Success 200 {object} Response "审批记录列表"
+// @Failure 400 {object} Response "查询参数无效"
+// @Router /api/v1/admin/approvals [get]
+func (c *ApprovalController) GetApprovals(ctx *gin.Context) {
+	req, err := c.ParsePageRequest(ctx)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	q, err := Utils.ParseListQuery(ctx.Request.URL.Query(), ApprovalQuerySpec)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	approvals, meta, err := c.approvalService.List(q, req)
+	if err != nil {
+		c.approvalError(ctx, err)
+		return
+	}
+	data, err := q.Project(approvals)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取审批记录失败: "+err.Error())
+		return
+	}
+	c.ListSuccess(ctx, data, meta, "审批记录获取成功")
+}
+
+// GetApproval 获取审批记录
+// @Summary 获取审批记录
+// @Description 包含待执行请求的方法、路径和请求体，供审批人核对（仅管理员）
+// @Tags 操作审批
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "审批记录ID"
+// @Success 200 {object} Response "审批记录"
+// @Failure 404 {object} Response "审批记录不存在"
+// @Router /api/v1/admin/approvals/{id} [get]
+func (c *ApprovalController) GetApproval(ctx *gin.Context) {
+	id, ok := c.approvalID(ctx)
+	if !ok {
+		return
+	}
+	approval, err := c.approvalService.Get(id)
+	if err != nil && !errors.Is(err, Services.ErrApprovalExpired) {
+		c.approvalError(ctx, err)
+		return
+	}
+	c.Success(ctx, approval, "审批记录获取成功")
+}
+
+// ApproveApproval 审批通过
+// @Summary 审批通过
+// @Description 发起人不能审批自己的操作；通过后发起人需要在执行期限内携带 X-Approval-ID 请求头重新提交原请求（仅管理员）
+// @Tags 操作审批
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "审批记录ID"
+// @Param request body ReviewApprovalRequest false "审批意见"
+// @Success 200 {object} Response "审批后的记录"
+// @Failure 403 {object} Response "不能审批自己发起的操作"
+// @Failure 404 {object} Response "审批记录不存在"
+// @Failure 409 {object} Response "审批记录不是待审批状态或已过期"
+// @Router /api/v1/admin/approvals/{id}/approve [post]
+func (c *ApprovalController) ApproveApproval(ctx *gin.Context) {
+	c.review(ctx, c.approvalService.Approve, "操作已审批通过")
+}
+
+// RejectApproval 拒绝审批
+// @Summary 拒绝审批
+// @Description 拒绝后该审批记录不能再执行（仅管理员）
+// @Tags 操作审批
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "审批记录ID"
+// @Param request body ReviewApprovalRequest false "拒绝原因"
+// @Success 200 {object} Response "审批后的记录"
+// @Failure 403 {object} Response "不能审批自己发起的操作"
+// @Failure 404 {object} Response "审批记录不存在"
+// @Failure 409 {object} Response "审批记录不是待审批状态或已过期"
+// @Router /api/v1/admin/approvals/{id}/reject [post]
+func (c *ApprovalController) RejectApproval(ctx *gin.Context) {
+	c.review(ctx, c.approvalService.Reject, "操作已拒绝")
+}
+
+// review 审批通过或拒绝
+func (c *ApprovalController) review(ctx *gin.Context, review func(id, reviewerID uint, reviewerName, comment string) (*Models.AdminApproval, error), message string) {
+	reviewerID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "用户未登录")
+		return
+	}
+	id, ok := c.approvalID(ctx)
+	if !ok {
+		return
+	}
+	var req ReviewApprovalRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			c.Error(ctx, http.StatusBadRequest, "请求参数无效: "+err.Error())
+			return
+		}
+	}
+
+	approval, err := review(id, reviewerID, ctx.GetString("username"), req.Comment)
+	if err != nil {
+		c.approvalError(ctx, err)
+		return
+	}
+	c.Success(ctx, approval, message)
+}
+
+// approvalID 解析路径中的审批记录ID，无效时返回400
+func (c *ApprovalController) approvalID(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.Error(ctx, http.StatusBadRequest, "审批记录ID无效")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// approvalError 审批错误响应
+func (c *ApprovalController) approvalError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, Services.ErrApprovalNotFound):
+		c.Error(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, Services.ErrApprovalSelfReview):
+		c.Error(ctx, http.StatusForbidden, err.Error())
+	case errors.Is(err, Services.ErrApprovalNotPending), errors.Is(err, Services.ErrApprovalExpired):
+		c.Error(ctx, http.StatusConflict, err.Error())
+	case errors.Is(err, Utils.ErrInvalidCursor), errors.Is(err, Utils.ErrInvalidQuery):
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+	default:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+}
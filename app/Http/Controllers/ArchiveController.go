@@ -0,0 +1,105 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Services"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ArchiveController 大表分区和归档控制器
+// 功能说明：
+// 1. 查看分区记录（时间范围、状态、归档文件位置和校验值）
+// 2. 手动执行一次分区维护和归档
+// 3. 按时间范围查询表数据，合并在线表和归档文件的结果
+type ArchiveController struct {
+	Controller
+	archiveService *Services.TableArchiveService
+}
+
+// NewArchiveController 创建大表分区和归档控制器
+func NewArchiveController(archiveService *Services.TableArchiveService) *ArchiveController {
+	return &ArchiveController{archiveService: archiveService}
+}
+
+// GetPartitions 获取分区列表
+// @Summary 获取分区列表
+// @Description 返回启用分区归档的表和各表的分区记录，可按表名筛选（仅管理员）
+// @Tags 数据归档
+// @Produce json
+// @Security ApiKeyAuth
+// @Param table query string false "表名"
+// @Success 200 {object} Response "分区列表"
+// @Router /api/v1/archive/partitions [get]
+func (c *ArchiveController) GetPartitions(ctx *gin.Context) {
+	partitions, err := c.archiveService.Partitions(ctx.Query("table"))
+	if err != nil {
+		c.ServerError(ctx, "获取分区列表失败")
+		return
+	}
+	c.Success(ctx, gin.H{
+		"tables":     c.archiveService.Tables(),
+		"partitions": partitions,
+	}, "获取分区列表成功")
+}
+
+// RunMaintenance 执行分区维护
+// @Summary 执行分区维护
+// @Description 立即创建或轮转分区，并归档超过在线保留时间的分区（仅管理员）
+// @Tags 数据归档
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "维护结果"
+// @Router /api/v1/archive/run [post]
+func (c *ArchiveController) RunMaintenance(ctx *gin.Context) {
+	result, err := c.archiveService.Run(ctx.Request.Context())
+	if err != nil {
+		c.ServerError(ctx, "分区维护失败: "+err.Error())
+		return
+	}
+	c.Success(ctx, result, "分区维护完成")
+}
+
+// QueryTable 查询表数据
+// @Summary 查询表数据
+// @Description 按时间范围查询，合并在线表、月表和归档文件的结果并按时间倒序分页（仅管理员）
+// @Tags 数据归档
+// @Produce json
+// @Security ApiKeyAuth
+// @Param table path string true "表名"
+// @Param start_time query string false "开始时间（RFC3339）"
+// @Param end_time query string false "结束时间（RFC3339）"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} Response "查询结果"
+// @Failure 404 {object} Response "表未启用分区归档"
+// @Router /api/v1/archive/tables/{table}/rows [get]
+func (c *ArchiveController) QueryTable(ctx *gin.Context) {
+	page, pageSize := c.ValidatePagination(ctx)
+	query := Services.ArchiveQuery{Offset: (page - 1) * pageSize, Limit: pageSize}
+	for param, target := range map[string]*time.Time{"start_time": &query.From, "end_time": &query.To} {
+		value := ctx.Query(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.Error(ctx, http.StatusBadRequest, "时间格式无效: "+param)
+			return
+		}
+		*target = parsed
+	}
+
+	rows, total, err := c.archiveService.QueryRows(ctx.Request.Context(), ctx.Param("table"), query)
+	if err != nil {
+		if errors.Is(err, Services.ErrArchiveTableNotSupported) {
+			c.NotFound(ctx, err.Error())
+			return
+		}
+		c.ServerError(ctx, "查询失败: "+err.Error())
+		return
+	}
+	c.PaginatedSuccess(ctx, rows, total, page, pageSize, "查询成功")
+}
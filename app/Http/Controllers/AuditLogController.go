@@ -0,0 +1,88 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Utils"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditLogController 审计日志查询控制器
+type AuditLogController struct {
+	Controller
+	auditService *Services.AuditService
+}
+
+// NewAuditLogController 创建审计日志查询控制器
+func NewAuditLogController(auditService *Services.AuditService) *AuditLogController {
+	return &AuditLogController{auditService: auditService}
+}
+
+// AuditLogQuerySpec 审计日志列表可筛选、排序和返回的字段
+var AuditLogQuerySpec = Utils.QuerySpec{
+	Fields: map[string]Utils.QueryField{
+		"id":              {Column: "id", Type: Utils.QueryInt, Filter: true, Sort: true},
+		"user_id":         {Column: "user_id", Type: Utils.QueryInt, Filter: true},
+		"username":        {Column: "username", Type: Utils.QueryString, Filter: true, Sort: true},
+		"action":          {Column: "action", Type: Utils.QueryString, Filter: true, Sort: true},
+		"level":           {Column: "level", Type: Utils.QueryString, Filter: true},
+		"resource":        {Column: "resource", Type: Utils.QueryString, Filter: true},
+		"resource_id":     {Column: "resource_id", Type: Utils.QueryInt, Filter: true},
+		"description":     {Column: "description", Type: Utils.QueryString, Filter: true},
+		"ip_address":      {Column: "ip_address", Type: Utils.QueryString, Filter: true},
+		"user_agent":      {Column: "user_agent", Type: Utils.QueryString},
+		"request_id":      {Column: "request_id", Type: Utils.QueryString, Filter: true},
+		"status":          {Column: "status", Type: Utils.QueryString, Filter: true},
+		"error_msg":       {Column: "error_msg", Type: Utils.QueryString},
+		"impersonator_id": {Column: "impersonator_id", Type: Utils.QueryInt, Filter: true},
+		"created_at":      {Column: "created_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+	},
+	DefaultSort: "-created_at",
+}
+
+// GetAuditLogs 获取审计日志列表
+// @Summary 获取审计日志列表
+// @Description 分页查询审计日志，支持 filter[field][op]=value 筛选、sort=-created_at 排序和 fields 选择返回字段（仅管理员）
+// @Tags 审计日志
+// @Produce json
+// @Security ApiKeyAuth
+// @Param filter[action][like] query string false "按操作类型筛选，字段和操作符见接口文档"
+// @Param sort query string false "排序字段，逗号分隔，前缀-表示降序" default(-created_at)
+// @Param fields query string false "返回字段，逗号分隔"
+// @Param page query int false "页码" default(1)
+// @Param limit query int false "每页数量" default(20)
+// @Param cursor query string false "上一页返回的 meta.cursor，传入时使用游标分页"
+// @Success 200 {object} Response "审计日志列表"
+// @Failure 400 {object} Response "查询参数无效"
+// @Router /api/v1/admin/audit-logs [get]
+func (c *AuditLogController) GetAuditLogs(ctx *gin.Context) {
+	req, err := c.ParsePageRequest(ctx)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	q, err := Utils.ParseListQuery(ctx.Request.URL.Query(), AuditLogQuerySpec)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	logs, meta, err := c.auditService.ListAuditLogs(q, req)
+	if errors.Is(err, Utils.ErrInvalidCursor) || errors.Is(err, Utils.ErrInvalidQuery) {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取审计日志失败: "+err.Error())
+		return
+	}
+
+	data, err := q.Project(logs)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取审计日志失败: "+err.Error())
+		return
+	}
+	c.ListSuccess(ctx, data, meta, "审计日志获取成功")
+}
@@ -1,8 +1,12 @@
 package Controllers
 
 import (
+	"cloud-platform-api/app/Database"
 	"cloud-platform-api/app/Http/Requests"
+	"cloud-platform-api/app/Models"
 	"cloud-platform-api/app/Services"
+	"errors"
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -15,7 +19,7 @@ import (
 // 2. 用户登录：JWT token认证，支持登录状态记录和统计
 // 3. 用户登出：token黑名单机制，支持多设备登录控制
 // 4. 资料管理：用户资料查看和更新，支持头像上传
-// 5. 密码管理：密码重置、邮箱验证、token刷新
+// 5. 密码管理：密码重置、密码修改、邮箱验证、token刷新
 // 6. 安全控制：账户状态检查、登录失败处理、会话管理
 //
 // 安全特性：
@@ -42,9 +46,21 @@ import (
 // - 邮箱验证是可选的，但建议启用
 // - 支持多设备同时登录
 type AuthController struct {
+	Controller
 	authService *Services.AuthService
 }
 
+// LoginResponse 登录成功返回的令牌和用户信息
+type LoginResponse struct {
+	Token string       `json:"token"`
+	User  *Models.User `json:"user"`
+}
+
+// TokenResponse 刷新后的令牌
+type TokenResponse struct {
+	Token string `json:"token"`
+}
+
 // NewAuthController 创建认证控制器
 // 功能说明：
 // 1. 初始化认证控制器实例
@@ -103,8 +119,8 @@ func (c *AuthController) Register(ctx *gin.Context) {
 		// JSON绑定失败，返回400错误
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"message": "请求数据无效",
-			"error":   err.Error(),
+			"message": c.Trans(ctx, "common.invalid_request"),
+			"error":   c.TransError(ctx, err),
 		})
 		return
 	}
@@ -116,7 +132,7 @@ func (c *AuthController) Register(ctx *gin.Context) {
 		// 验证失败，返回详细错误信息
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"message": "请求验证失败",
+			"message": c.Trans(ctx, "common.validation_failed"),
 			"errors":  validationErrors, // 包含所有验证错误
 		})
 		return
@@ -130,8 +146,8 @@ func (c *AuthController) Register(ctx *gin.Context) {
 		// 可能的原因：用户名或邮箱已存在、数据库错误等
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"message": "注册失败",
-			"error":   err.Error(),
+			"message": c.Trans(ctx, "auth.register_failed"),
+			"error":   c.TransError(ctx, err),
 		})
 		return
 	}
@@ -140,7 +156,7 @@ func (c *AuthController) Register(ctx *gin.Context) {
 	// 注意：返回的用户信息不包含密码
 	ctx.JSON(http.StatusCreated, gin.H{
 		"success": true,
-		"message": "注册成功",
+		"message": c.Trans(ctx, "auth.register_success"),
 		"data":    user,
 	})
 }
@@ -176,32 +192,51 @@ func (c *AuthController) Login(ctx *gin.Context) {
 	if err := ctx.ShouldBindJSON(&request); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"message": "请求数据无效",
-			"error":   err.Error(),
+			"message": c.Trans(ctx, "common.invalid_request"),
+			"error":   c.TransError(ctx, err),
 		})
 		return
 	}
 
 	token, user, err := c.authService.Login(request)
+	c.recordLoginAttempt(ctx, request.Username, err)
 	if err != nil {
 		ctx.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
-			"message": "登录失败",
-			"error":   err.Error(),
+			"message": c.Trans(ctx, "auth.login_failed"),
+			"error":   c.TransError(ctx, err),
 		})
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "登录成功",
-		"data": gin.H{
-			"token": token,
-			"user":  user,
-		},
+		"message": c.Trans(ctx, "auth.login_success"),
+		"data":    LoginResponse{Token: token, User: user},
 	})
 }
 
+// recordLoginAttempt 记录登录尝试，用于用户查询登录历史和新设备登录提醒，记录失败不影响登录结果
+func (c *AuthController) recordLoginAttempt(ctx *gin.Context, username string, loginErr error) {
+	service := Services.DefaultLoginHistoryService(Database.GetDB())
+	if service == nil {
+		return
+	}
+	record := Services.LoginRecord{
+		Username:  username,
+		IPAddress: ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+		Country:   service.Country(ctx.Request.Header),
+		Success:   loginErr == nil,
+	}
+	if loginErr != nil {
+		record.FailureReason = loginErr.Error()
+	}
+	if _, err := service.Record(ctx.Request.Context(), record); err != nil {
+		log.Printf("记录登录尝试失败: %v", err)
+	}
+}
+
 // Logout 用户登出
 // 功能说明：
 // 1. 处理用户登出请求
@@ -214,7 +249,7 @@ func (c *AuthController) Logout(ctx *gin.Context) {
 	if userID == "" {
 		ctx.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
-			"message": "未授权访问",
+			"message": c.Trans(ctx, "common.unauthorized"),
 		})
 		return
 	}
@@ -223,15 +258,15 @@ func (c *AuthController) Logout(ctx *gin.Context) {
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"message": "登出失败",
-			"error":   err.Error(),
+			"message": c.Trans(ctx, "auth.logout_failed"),
+			"error":   c.TransError(ctx, err),
 		})
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "登出成功",
+		"message": c.Trans(ctx, "auth.logout_success"),
 	})
 }
 
@@ -247,7 +282,7 @@ func (c *AuthController) GetProfile(ctx *gin.Context) {
 	if userID == "" {
 		ctx.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
-			"message": "未授权访问",
+			"message": c.Trans(ctx, "common.unauthorized"),
 		})
 		return
 	}
@@ -256,12 +291,13 @@ func (c *AuthController) GetProfile(ctx *gin.Context) {
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{
 			"success": false,
-			"message": "用户不存在",
-			"error":   err.Error(),
+			"message": c.Trans(ctx, "auth.user_not_found"),
+			"error":   c.TransError(ctx, err),
 		})
 		return
 	}
 
+	c.SetETag(ctx, user.Version)
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    user,
@@ -274,13 +310,14 @@ func (c *AuthController) GetProfile(ctx *gin.Context) {
 // 2. 支持更新用户名、邮箱、头像等字段
 // 3. 验证数据唯一性（用户名、邮箱不能重复）
 // 4. 需要有效的JWT token才能访问
-// 5. 返回更新后的用户信息
+// 5. 支持 If-Match 乐观锁，资料已被其他请求修改时返回409和当前版本
+// 6. 返回更新后的用户信息，ETag 响应头为新版本
 func (c *AuthController) UpdateProfile(ctx *gin.Context) {
 	userID := ctx.GetString("user_id")
 	if userID == "" {
 		ctx.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
-			"message": "未授权访问",
+			"message": c.Trans(ctx, "common.unauthorized"),
 		})
 		return
 	}
@@ -289,25 +326,36 @@ func (c *AuthController) UpdateProfile(ctx *gin.Context) {
 	if err := ctx.ShouldBindJSON(&request); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"message": "请求数据无效",
-			"error":   err.Error(),
+			"message": c.Trans(ctx, "common.invalid_request"),
+			"error":   c.TransError(ctx, err),
 		})
 		return
 	}
 
+	var ok bool
+	if request.Version, ok = c.ExpectedVersion(ctx, request.Version); !ok {
+		return
+	}
+
 	user, err := c.authService.UpdateProfile(userID, request)
+	var conflict *Models.VersionConflictError
+	if errors.As(err, &conflict) {
+		c.VersionConflict(ctx, conflict)
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"message": "更新失败",
-			"error":   err.Error(),
+			"message": c.Trans(ctx, "auth.profile_update_failed"),
+			"error":   c.TransError(ctx, err),
 		})
 		return
 	}
 
+	c.SetETag(ctx, user.Version)
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "更新成功",
+		"message": c.Trans(ctx, "auth.profile_updated"),
 		"data":    user,
 	})
 }
@@ -325,7 +373,7 @@ func (c *AuthController) RefreshToken(ctx *gin.Context) {
 	if token == "" {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"message": "Token不能为空",
+			"message": c.Trans(ctx, "auth.token_required"),
 		})
 		return
 	}
@@ -334,18 +382,16 @@ func (c *AuthController) RefreshToken(ctx *gin.Context) {
 	if err != nil {
 		ctx.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
-			"message": "Token刷新失败",
-			"error":   err.Error(),
+			"message": c.Trans(ctx, "auth.token_refresh_failed"),
+			"error":   c.TransError(ctx, err),
 		})
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Token刷新成功",
-		"data": gin.H{
-			"token": newToken,
-		},
+		"message": c.Trans(ctx, "auth.token_refreshed"),
+		"data":    TokenResponse{Token: newToken},
 	})
 }
 
@@ -364,8 +410,8 @@ func (c *AuthController) RequestPasswordReset(ctx *gin.Context) {
 	if err := ctx.ShouldBindJSON(&request); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"message": "邮箱格式无效",
-			"error":   err.Error(),
+			"message": c.Trans(ctx, "auth.invalid_email"),
+			"error":   c.TransError(ctx, err),
 		})
 		return
 	}
@@ -374,15 +420,15 @@ func (c *AuthController) RequestPasswordReset(ctx *gin.Context) {
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"message": "密码重置请求失败",
-			"error":   err.Error(),
+			"message": c.Trans(ctx, "auth.password_reset_request_failed"),
+			"error":   c.TransError(ctx, err),
 		})
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "密码重置邮件已发送",
+		"message": c.Trans(ctx, "auth.password_reset_sent"),
 	})
 }
 
@@ -402,8 +448,8 @@ func (c *AuthController) ResetPassword(ctx *gin.Context) {
 	if err := ctx.ShouldBindJSON(&request); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"message": "请求数据无效",
-			"error":   err.Error(),
+			"message": c.Trans(ctx, "common.invalid_request"),
+			"error":   c.TransError(ctx, err),
 		})
 		return
 	}
@@ -412,15 +458,56 @@ func (c *AuthController) ResetPassword(ctx *gin.Context) {
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"message": "密码重置失败",
-			"error":   err.Error(),
+			"message": c.Trans(ctx, "auth.password_reset_failed"),
+			"error":   c.TransError(ctx, err),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": c.Trans(ctx, "auth.password_reset_success"),
+	})
+}
+
+// ChangePassword 修改密码
+// 功能说明：
+// 1. 验证当前密码，更新为符合强度要求的新密码
+// 2. 清除必须修改密码标记，密码过期时间从本次修改重新计算
+// 3. 必须修改密码的用户也可以访问此接口
+// 4. 需要有效的JWT token才能访问
+func (c *AuthController) ChangePassword(ctx *gin.Context) {
+	userID := ctx.GetString("user_id")
+	if userID == "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": c.Trans(ctx, "common.unauthorized"),
+		})
+		return
+	}
+
+	var request Requests.PasswordChangeRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": c.Trans(ctx, "common.invalid_request"),
+			"error":   c.TransError(ctx, err),
+		})
+		return
+	}
+
+	if err := c.authService.ChangePassword(userID, request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": c.Trans(ctx, "auth.password_change_failed"),
+			"error":   c.TransError(ctx, err),
 		})
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "密码重置成功",
+		"message": c.Trans(ctx, "auth.password_changed"),
 	})
 }
 
@@ -437,7 +524,7 @@ func (c *AuthController) SendEmailVerification(ctx *gin.Context) {
 	if userID == "" {
 		ctx.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
-			"message": "未授权访问",
+			"message": c.Trans(ctx, "common.unauthorized"),
 		})
 		return
 	}
@@ -446,15 +533,15 @@ func (c *AuthController) SendEmailVerification(ctx *gin.Context) {
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"message": "邮箱验证邮件发送失败",
-			"error":   err.Error(),
+			"message": c.Trans(ctx, "auth.verification_send_failed"),
+			"error":   c.TransError(ctx, err),
 		})
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "邮箱验证邮件已发送",
+		"message": c.Trans(ctx, "auth.verification_sent"),
 	})
 }
 
@@ -473,8 +560,8 @@ func (c *AuthController) VerifyEmail(ctx *gin.Context) {
 	if err := ctx.ShouldBindJSON(&request); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"message": "验证token无效",
-			"error":   err.Error(),
+			"message": c.Trans(ctx, "auth.verification_token_invalid"),
+			"error":   c.TransError(ctx, err),
 		})
 		return
 	}
@@ -483,14 +570,14 @@ func (c *AuthController) VerifyEmail(ctx *gin.Context) {
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"message": "邮箱验证失败",
-			"error":   err.Error(),
+			"message": c.Trans(ctx, "auth.email_verify_failed"),
+			"error":   c.TransError(ctx, err),
 		})
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "邮箱验证成功",
+		"message": c.Trans(ctx, "auth.email_verified"),
 	})
 }
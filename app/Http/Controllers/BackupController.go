@@ -0,0 +1,403 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Services"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BackupController 备份管理控制器
+//
+// 功能说明：
+// 1. 列出备份及其清单（包含的表、行数和文件数）
+// 2. 按表或逻辑领域（如 security、monitoring）创建备份
+// 3. 从按表备份、数据库备份或完整备份中只恢复选择的表，其他表不受影响
+// 4. 管理定时备份计划，手动触发运行并轮询进度，查看每个计划的运行记录
+//
+// 安全特性：
+// - 所有接口需要管理员权限（在路由中配置）
+// - 接口只支持按表恢复，覆盖整个数据库的恢复需停止服务后使用 cloudctl backup restore
+type BackupController struct {
+	Controller
+	backupService   *Services.BackupService
+	scheduleService *Services.BackupScheduleService
+}
+
+// NewBackupController 创建备份管理控制器
+func NewBackupController(backupService *Services.BackupService) *BackupController {
+	return &BackupController{backupService: backupService}
+}
+
+// SetScheduleService 设置定时备份服务
+func (c *BackupController) SetScheduleService(scheduleService *Services.BackupScheduleService) {
+	c.scheduleService = scheduleService
+}
+
+// BackupRestoreRequest 按表恢复请求
+type BackupRestoreRequest struct {
+	Tables  []string `json:"tables"`  // 恢复的表
+	Domains []string `json:"domains"` // 恢复的领域，与 tables 合并；按表备份两者都为空时恢复备份中的全部表
+	Confirm bool     `json:"confirm"` // 确认清空并覆盖选择的表
+}
+
+// BackupRunQuery 运行记录查询参数
+type BackupRunQuery struct {
+	Limit int `form:"limit"` // 返回条数，默认20，最多100
+}
+
+// GetBackups 获取备份列表
+// @Summary 获取备份列表
+// @Tags 备份管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "备份列表"
+// @Router /api/v1/admin/backups [get]
+func (c *BackupController) GetBackups(ctx *gin.Context) {
+	backups, err := c.backupService.ListBackups()
+	if err != nil {
+		c.backupError(ctx, err)
+		return
+	}
+	c.Success(ctx, backups, "备份列表获取成功")
+}
+
+// GetBackupDomains 获取备份领域
+// @Summary 获取备份领域及其包含的表
+// @Tags 备份管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "领域到表的映射"
+// @Router /api/v1/admin/backups/domains [get]
+func (c *BackupController) GetBackupDomains(ctx *gin.Context) {
+	domains, err := c.backupService.ListBackupDomains()
+	if err != nil {
+		c.backupError(ctx, err)
+		return
+	}
+	c.Success(ctx, domains, "备份领域获取成功")
+}
+
+// CreateTableBackup 按表创建备份
+// @Summary 按表或领域创建备份
+// @Tags 备份管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param selection body Services.BackupSelection true "备份的表和领域"
+// @Success 201 {object} Response "创建的备份"
+// @Failure 400 {object} Response "表或领域不存在"
+// @Router /api/v1/admin/backups/tables [post]
+func (c *BackupController) CreateTableBackup(ctx *gin.Context) {
+	var selection Services.BackupSelection
+	if err := ctx.ShouldBindJSON(&selection); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	backup, err := c.backupService.CreateTableBackup(ctx.Request.Context(), selection)
+	if err != nil {
+		c.backupError(ctx, err)
+		return
+	}
+	c.Created(ctx, backup, "备份创建成功")
+}
+
+// GetBackupManifest 获取备份清单
+// @Summary 获取备份清单
+// @Tags 备份管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "备份ID或文件名"
+// @Success 200 {object} Response "备份清单"
+// @Failure 404 {object} Response "备份或清单不存在"
+// @Router /api/v1/admin/backups/{id}/manifest [get]
+func (c *BackupController) GetBackupManifest(ctx *gin.Context) {
+	backup, err := c.backupService.FindBackup(ctx.Param("id"))
+	if err != nil {
+		c.backupError(ctx, err)
+		return
+	}
+	if backup.Manifest == nil {
+		c.Error(ctx, http.StatusNotFound, "备份没有清单")
+		return
+	}
+	c.Success(ctx, backup.Manifest, "备份清单获取成功")
+}
+
+// RestoreBackupTables 从备份中恢复选择的表
+// @Summary 从备份中恢复选择的表
+// @Description 选择的表在一个事务中清空并写入备份中的数据，其他表不受影响；数据库备份和完整备份必须选择表或领域
+// @Tags 备份管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "备份ID或文件名"
+// @Param request body BackupRestoreRequest true "恢复的表和领域"
+// @Success 200 {object} Response "恢复的表和行数"
+// @Failure 400 {object} Response "未确认或表不存在"
+// @Failure 404 {object} Response "备份不存在"
+// @Failure 422 {object} Response "备份校验或解密失败"
+// @Router /api/v1/admin/backups/{id}/restore [post]
+func (c *BackupController) RestoreBackupTables(ctx *gin.Context) {
+	var req BackupRestoreRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	if !req.Confirm {
+		c.Error(ctx, http.StatusBadRequest, "恢复会清空并覆盖选择的表，请设置 confirm 为 true")
+		return
+	}
+	backup, err := c.backupService.FindBackup(ctx.Param("id"))
+	if err != nil {
+		c.backupError(ctx, err)
+		return
+	}
+	if backup.Type != "tables" && len(req.Tables) == 0 && len(req.Domains) == 0 {
+		c.Error(ctx, http.StatusBadRequest, Services.ErrBackupSelectionEmpty.Error())
+		return
+	}
+	result, err := c.backupService.RestoreSelected(ctx.Request.Context(), backup.Path, Services.BackupSelection{
+		Tables:  req.Tables,
+		Domains: req.Domains,
+	})
+	if err != nil {
+		c.backupError(ctx, err)
+		return
+	}
+	c.Success(ctx, result, "备份恢复成功")
+}
+
+// GetBackupSchedules 获取定时备份列表
+// @Summary 获取定时备份列表
+// @Tags 备份管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "定时备份列表，包含上次运行结果"
+// @Router /api/v1/admin/backups/schedules [get]
+func (c *BackupController) GetBackupSchedules(ctx *gin.Context) {
+	schedules, err := c.scheduleService.ListSchedules()
+	if err != nil {
+		c.backupError(ctx, err)
+		return
+	}
+	c.Success(ctx, schedules, "定时备份列表获取成功")
+}
+
+// GetBackupSchedule 获取定时备份
+// @Summary 获取定时备份
+// @Tags 备份管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "定时备份ID"
+// @Success 200 {object} Response "定时备份"
+// @Failure 404 {object} Response "定时备份不存在"
+// @Router /api/v1/admin/backups/schedules/{id} [get]
+func (c *BackupController) GetBackupSchedule(ctx *gin.Context) {
+	id, ok := c.pathID(ctx, "无效的定时备份ID")
+	if !ok {
+		return
+	}
+	schedule, err := c.scheduleService.GetSchedule(id)
+	if err != nil {
+		c.backupError(ctx, err)
+		return
+	}
+	c.Success(ctx, schedule, "定时备份获取成功")
+}
+
+// CreateBackupSchedule 创建定时备份
+// @Summary 创建定时备份
+// @Description type 为 database、files、full 或 tables，tables 类型需要选择表或领域；target 为 local 或 archive
+// @Tags 备份管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param schedule body Services.BackupScheduleInput true "定时备份"
+// @Success 201 {object} Response "创建的定时备份"
+// @Failure 400 {object} Response "参数无效"
+// @Router /api/v1/admin/backups/schedules [post]
+func (c *BackupController) CreateBackupSchedule(ctx *gin.Context) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "需要登录")
+		return
+	}
+	var input Services.BackupScheduleInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	schedule, err := c.scheduleService.CreateSchedule(input, userID)
+	if err != nil {
+		c.backupError(ctx, err)
+		return
+	}
+	c.Created(ctx, schedule, "定时备份已创建")
+}
+
+// UpdateBackupSchedule 更新定时备份
+// @Summary 更新定时备份
+// @Tags 备份管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "定时备份ID"
+// @Param schedule body Services.BackupScheduleInput true "定时备份"
+// @Success 200 {object} Response "更新后的定时备份"
+// @Failure 400 {object} Response "参数无效"
+// @Failure 404 {object} Response "定时备份不存在"
+// @Router /api/v1/admin/backups/schedules/{id} [put]
+func (c *BackupController) UpdateBackupSchedule(ctx *gin.Context) {
+	id, ok := c.pathID(ctx, "无效的定时备份ID")
+	if !ok {
+		return
+	}
+	var input Services.BackupScheduleInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	schedule, err := c.scheduleService.UpdateSchedule(id, input)
+	if err != nil {
+		c.backupError(ctx, err)
+		return
+	}
+	c.Success(ctx, schedule, "定时备份已更新")
+}
+
+// DeleteBackupSchedule 删除定时备份
+// @Summary 删除定时备份
+// @Description 同时删除运行记录，已创建的备份文件保留
+// @Tags 备份管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "定时备份ID"
+// @Success 200 {object} Response "删除成功"
+// @Failure 404 {object} Response "定时备份不存在"
+// @Router /api/v1/admin/backups/schedules/{id} [delete]
+func (c *BackupController) DeleteBackupSchedule(ctx *gin.Context) {
+	id, ok := c.pathID(ctx, "无效的定时备份ID")
+	if !ok {
+		return
+	}
+	if err := c.scheduleService.DeleteSchedule(id); err != nil {
+		c.backupError(ctx, err)
+		return
+	}
+	c.Success(ctx, nil, "定时备份已删除")
+}
+
+// RunBackupSchedule 手动触发定时备份
+// @Summary 手动触发定时备份
+// @Description 备份在后台执行，返回运行记录，通过 GET /api/v1/admin/backups/runs/{id} 轮询进度；不影响下次运行时间
+// @Tags 备份管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "定时备份ID"
+// @Success 200 {object} Response "运行记录"
+// @Failure 404 {object} Response "定时备份不存在"
+// @Failure 409 {object} Response "定时备份正在运行"
+// @Router /api/v1/admin/backups/schedules/{id}/run [post]
+func (c *BackupController) RunBackupSchedule(ctx *gin.Context) {
+	id, ok := c.pathID(ctx, "无效的定时备份ID")
+	if !ok {
+		return
+	}
+	userID, _ := c.GetCurrentUser(ctx)
+	run, err := c.scheduleService.TriggerSchedule(id, userID)
+	if err != nil {
+		c.backupError(ctx, err)
+		return
+	}
+	c.Success(ctx, run, "备份任务已提交")
+}
+
+// GetBackupScheduleRuns 获取定时备份的运行记录
+// @Summary 获取定时备份的运行记录
+// @Tags 备份管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "定时备份ID"
+// @Param limit query int false "返回条数，默认20，最多100"
+// @Success 200 {object} Response "运行记录，按时间倒序"
+// @Failure 404 {object} Response "定时备份不存在"
+// @Router /api/v1/admin/backups/schedules/{id}/runs [get]
+func (c *BackupController) GetBackupScheduleRuns(ctx *gin.Context) {
+	id, ok := c.pathID(ctx, "无效的定时备份ID")
+	if !ok {
+		return
+	}
+	var query BackupRunQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	runs, err := c.scheduleService.ListRuns(id, query.Limit)
+	if err != nil {
+		c.backupError(ctx, err)
+		return
+	}
+	c.Success(ctx, runs, "运行记录获取成功")
+}
+
+// GetBackupRun 获取备份运行记录
+// @Summary 获取备份运行记录
+// @Description 返回运行状态、阶段和进度，用于轮询手动触发的备份
+// @Tags 备份管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "运行记录ID"
+// @Success 200 {object} Response "运行记录"
+// @Failure 404 {object} Response "运行记录不存在"
+// @Router /api/v1/admin/backups/runs/{id} [get]
+func (c *BackupController) GetBackupRun(ctx *gin.Context) {
+	id, ok := c.pathID(ctx, "无效的运行记录ID")
+	if !ok {
+		return
+	}
+	run, err := c.scheduleService.GetRun(id)
+	if err != nil {
+		c.backupError(ctx, err)
+		return
+	}
+	c.Success(ctx, run, "运行记录获取成功")
+}
+
+// pathID 解析路径中的ID
+func (c *BackupController) pathID(ctx *gin.Context, message string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		c.Error(ctx, http.StatusBadRequest, message)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// backupError 按错误类型返回状态码
+func (c *BackupController) backupError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, Services.ErrBackupNotFound), errors.Is(err, os.ErrNotExist),
+		errors.Is(err, Services.ErrBackupScheduleNotFound), errors.Is(err, Services.ErrBackupRunNotFound):
+		c.Error(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, Services.ErrBackupSelectionEmpty),
+		errors.Is(err, Services.ErrUnknownBackupDomain),
+		errors.Is(err, Services.ErrUnknownBackupTable),
+		errors.Is(err, Services.ErrInvalidBackupSchedule):
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+	case errors.Is(err, Services.ErrBackupRunInProgress):
+		c.Error(ctx, http.StatusConflict, err.Error())
+	case errors.Is(err, Services.ErrBackupChecksumMismatch),
+		errors.Is(err, Services.ErrBackupDecryptFailed),
+		errors.Is(err, Services.ErrBackupKeyUnavailable),
+		errors.Is(err, Services.ErrBackupKeyVersionUnknown):
+		c.Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	default:
+		log.Printf("备份操作失败: %v", err)
+		c.Error(ctx, http.StatusInternalServerError, "备份操作失败")
+	}
+}
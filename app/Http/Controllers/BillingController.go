@@ -0,0 +1,281 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Services"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxStripeWebhookBody Stripe Webhook 请求体的最大长度
+const maxStripeWebhookBody = 1 << 20
+
+// BillingController 计费控制器
+//
+// 功能说明：
+// 1. 用户查看可订阅的套餐和自己当前的订阅，通过 Stripe 支付页面订阅
+// 2. 用户预览更换套餐的按比例计费金额后更换套餐，或在周期结束时取消订阅
+// 3. 管理员维护套餐的 Stripe 价格、用量限额和高级功能
+// 4. 接收 Stripe Webhook 同步订阅状态
+//
+// 安全特性：
+// - Webhook 不需要登录，使用 Stripe 签名校验；其他接口都需要登录（在路由中配置），套餐管理需要管理员权限
+type BillingController struct {
+	Controller
+	billingService *Services.BillingService
+}
+
+// BillingPlanRequest 订阅或更换套餐请求
+type BillingPlanRequest struct {
+	Plan          string     `json:"plan" binding:"required"` // 套餐代码
+	ProrationDate *time.Time `json:"proration_date"`          // 预览返回的按比例计费时间点，仅更换套餐时使用
+}
+
+// NewBillingController 创建计费控制器
+func NewBillingController(billingService *Services.BillingService) *BillingController {
+	return &BillingController{billingService: billingService}
+}
+
+// GetPlans 获取可订阅的套餐
+// @Summary 获取套餐列表
+// @Tags 计费
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "套餐列表"
+// @Router /api/v1/billing/plans [get]
+func (c *BillingController) GetPlans(ctx *gin.Context) {
+	plans, err := c.billingService.Plans(false)
+	if err != nil {
+		c.billingError(ctx, err)
+		return
+	}
+	c.Success(ctx, plans, "套餐列表获取成功")
+}
+
+// GetSubscription 获取当前用户的订阅
+// @Summary 获取我的订阅
+// @Description 返回当前套餐、订阅状态和可用的高级功能，未订阅时 plan 为空
+// @Tags 计费
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "订阅信息"
+// @Router /api/v1/billing/subscription [get]
+func (c *BillingController) GetSubscription(ctx *gin.Context) {
+	userID, ok := c.currentUser(ctx)
+	if !ok {
+		return
+	}
+	subscription, err := c.billingService.Subscription(userID)
+	if err != nil {
+		c.billingError(ctx, err)
+		return
+	}
+	c.Success(ctx, subscription, "订阅信息获取成功")
+}
+
+// Checkout 创建订阅支付页面
+// @Summary 订阅套餐
+// @Description 返回 Stripe 支付页面地址，支付完成后订阅通过 Webhook 生效；已有有效订阅时应更换套餐
+// @Tags 计费
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body BillingPlanRequest true "套餐"
+// @Success 200 {object} Response "支付页面地址"
+// @Failure 409 {object} Response "已有有效的订阅"
+// @Router /api/v1/billing/checkout [post]
+func (c *BillingController) Checkout(ctx *gin.Context) {
+	userID, ok := c.currentUser(ctx)
+	if !ok {
+		return
+	}
+	var req BillingPlanRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	url, err := c.billingService.Checkout(ctx.Request.Context(), userID, strings.TrimSpace(req.Plan))
+	if err != nil {
+		c.billingError(ctx, err)
+		return
+	}
+	c.Success(ctx, gin.H{"url": url}, "支付页面已创建")
+}
+
+// PreviewChange 预览更换套餐
+// @Summary 预览更换套餐
+// @Description 返回更换后下一张账单的金额和其中的按比例计费金额，更换套餐时传回 proration_date 使金额与预览一致
+// @Tags 计费
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body BillingPlanRequest true "目标套餐"
+// @Success 200 {object} Response "账单预览"
+// @Router /api/v1/billing/subscription/preview [post]
+func (c *BillingController) PreviewChange(ctx *gin.Context) {
+	userID, ok := c.currentUser(ctx)
+	if !ok {
+		return
+	}
+	var req BillingPlanRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	preview, err := c.billingService.PreviewChange(ctx.Request.Context(), userID, strings.TrimSpace(req.Plan))
+	if err != nil {
+		c.billingError(ctx, err)
+		return
+	}
+	c.Success(ctx, preview, "账单预览获取成功")
+}
+
+// ChangePlan 更换套餐
+// @Summary 更换套餐
+// @Description 按配置的方式按比例计费，新套餐的限额和功能立即生效
+// @Tags 计费
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body BillingPlanRequest true "目标套餐"
+// @Success 200 {object} Response "更换后的订阅"
+// @Router /api/v1/billing/subscription [put]
+func (c *BillingController) ChangePlan(ctx *gin.Context) {
+	userID, ok := c.currentUser(ctx)
+	if !ok {
+		return
+	}
+	var req BillingPlanRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	var prorationDate time.Time
+	if req.ProrationDate != nil {
+		prorationDate = *req.ProrationDate
+	}
+	account, err := c.billingService.ChangePlan(ctx.Request.Context(), userID, strings.TrimSpace(req.Plan), prorationDate)
+	if err != nil {
+		c.billingError(ctx, err)
+		return
+	}
+	c.Success(ctx, account, "套餐已更换")
+}
+
+// CancelSubscription 取消订阅
+// @Summary 取消订阅
+// @Description 在当前计费周期结束时取消，周期结束前仍可使用当前套餐
+// @Tags 计费
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "取消后的订阅"
+// @Router /api/v1/billing/subscription [delete]
+func (c *BillingController) CancelSubscription(ctx *gin.Context) {
+	userID, ok := c.currentUser(ctx)
+	if !ok {
+		return
+	}
+	account, err := c.billingService.Cancel(ctx.Request.Context(), userID)
+	if err != nil {
+		c.billingError(ctx, err)
+		return
+	}
+	c.Success(ctx, account, "订阅将在当前周期结束时取消")
+}
+
+// GetAllPlans 获取全部套餐，包括不可订阅的套餐
+// @Summary 获取全部套餐
+// @Tags 计费
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "套餐列表"
+// @Router /api/v1/admin/billing/plans [get]
+func (c *BillingController) GetAllPlans(ctx *gin.Context) {
+	plans, err := c.billingService.Plans(true)
+	if err != nil {
+		c.billingError(ctx, err)
+		return
+	}
+	c.Success(ctx, plans, "套餐列表获取成功")
+}
+
+// SavePlan 创建或更新套餐
+// @Summary 保存套餐
+// @Description 按套餐代码创建或更新，限额为0表示不限制；限额和功能的变化对已订阅的用户立即生效
+// @Tags 计费
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param plan body Services.BillingPlanInput true "套餐"
+// @Success 200 {object} Response "保存后的套餐"
+// @Router /api/v1/admin/billing/plans [put]
+func (c *BillingController) SavePlan(ctx *gin.Context) {
+	var input Services.BillingPlanInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	plan, err := c.billingService.SavePlan(input)
+	if err != nil {
+		c.billingError(ctx, err)
+		return
+	}
+	c.Success(ctx, plan, "套餐已保存")
+}
+
+// StripeWebhook 接收 Stripe Webhook
+// @Summary Stripe Webhook
+// @Description 同步订阅的创建、变更和取消，使用 Stripe-Signature 请求头校验签名；处理失败时返回500由 Stripe 重新投递
+// @Tags 计费
+// @Accept json
+// @Produce json
+// @Param Stripe-Signature header string true "Stripe 签名"
+// @Success 200 {object} Response "已处理"
+// @Failure 400 {object} Response "签名无效"
+// @Router /api/v1/billing/stripe/webhook [post]
+func (c *BillingController) StripeWebhook(ctx *gin.Context) {
+	payload, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxStripeWebhookBody))
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, "读取请求体失败")
+		return
+	}
+	if err := c.billingService.HandleWebhook(payload, ctx.GetHeader("Stripe-Signature")); err != nil {
+		if errors.Is(err, Services.ErrInvalidStripeSignature) {
+			c.Error(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("处理 Stripe Webhook 失败: %v", err)
+		c.Error(ctx, http.StatusInternalServerError, "处理 Stripe Webhook 失败")
+		return
+	}
+	c.Success(ctx, nil, "已处理")
+}
+
+// currentUser 获取当前用户，未登录时返回401
+func (c *BillingController) currentUser(ctx *gin.Context) (uint, bool) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "需要登录")
+		return 0, false
+	}
+	return userID, true
+}
+
+// billingError 按错误类型返回响应
+func (c *BillingController) billingError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, Services.ErrBillingPlanNotFound):
+		c.Error(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, Services.ErrInvalidBillingPlan), errors.Is(err, Services.ErrInvalidProrationDate):
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+	case errors.Is(err, Services.ErrBillingNotSubscribed), errors.Is(err, Services.ErrBillingAlreadySubscribed):
+		c.Error(ctx, http.StatusConflict, err.Error())
+	default:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+}
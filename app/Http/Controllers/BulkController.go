@@ -0,0 +1,323 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// BulkController 用户和角色批量操作控制器
+type BulkController struct {
+	Controller
+	bulkService *Services.BulkOperationService
+}
+
+// NewBulkController 创建批量操作控制器
+func NewBulkController(bulkService *Services.BulkOperationService) *BulkController {
+	return &BulkController{bulkService: bulkService}
+}
+
+// bulkUsersRequest 批量角色分配和启用/禁用请求
+type bulkUsersRequest struct {
+	UserIDs []uint `json:"user_ids" binding:"required,min=1"`
+	Role    string `json:"role"`
+}
+
+// ImportUsers 导入用户
+// @Summary 导入用户
+// @Description 上传CSV或JSON文件（表单字段 file）或直接提交请求体导入用户，任务异步执行（仅管理员）
+// @Tags 批量操作
+// @Accept multipart/form-data,text/csv,json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param file formData file false "导入文件(.csv/.json)"
+// @Param format query string false "文件格式(csv/json)，默认按文件扩展名或Content-Type判断"
+// @Param dry_run query bool false "只校验不写入"
+// @Success 200 {object} Response "批量任务"
+// @Router /api/v1/admin/bulk/users/import [post]
+func (c *BulkController) ImportUsers(ctx *gin.Context) {
+	operatorID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "用户未登录")
+		return
+	}
+
+	format := ctx.Query("format")
+	var reader io.Reader
+	if file, header, err := ctx.Request.FormFile("file"); err == nil {
+		defer file.Close()
+		reader = file
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+		}
+	} else {
+		reader = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, c.bulkService.MaxUploadSize()+1)
+		if format == "" {
+			format = "json"
+			if strings.Contains(ctx.ContentType(), "csv") {
+				format = "csv"
+			}
+		}
+	}
+
+	rows, err := c.bulkService.ParseUserImport(format, reader)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, "导入文件无效: "+err.Error())
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(ctx.Query("dry_run"))
+	job, err := c.bulkService.SubmitUserImport(rows, dryRun, operatorID)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "提交导入任务失败: "+err.Error())
+		return
+	}
+	c.Success(ctx, job, "导入任务已提交")
+}
+
+// AssignRoles 批量分配角色
+// @Summary 批量分配角色
+// @Description 为多个用户设置角色，任务异步执行，角色变更后用户需要重新登录（仅管理员）
+// @Tags 批量操作
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body bulkUsersRequest true "用户ID和角色(admin/user)"
+// @Success 200 {object} Response "批量任务"
+// @Router /api/v1/admin/bulk/users/roles [post]
+func (c *BulkController) AssignRoles(ctx *gin.Context) {
+	operatorID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "用户未登录")
+		return
+	}
+	var req bulkUsersRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "请求参数无效: "+err.Error())
+		return
+	}
+
+	job, err := c.bulkService.SubmitRoleAssignment(req.UserIDs, req.Role, operatorID)
+	if err != nil {
+		c.submitError(ctx, err)
+		return
+	}
+	c.Success(ctx, job, "角色分配任务已提交")
+}
+
+// DisableUsers 批量禁用用户
+// @Summary 批量禁用用户
+// @Description 禁用多个用户并撤销其已签发的token，任务异步执行（仅管理员）
+// @Tags 批量操作
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body bulkUsersRequest true "用户ID"
+// @Success 200 {object} Response "批量任务"
+// @Router /api/v1/admin/bulk/users/disable [post]
+func (c *BulkController) DisableUsers(ctx *gin.Context) {
+	c.changeStatus(ctx, 0)
+}
+
+// EnableUsers 批量启用用户
+// @Summary 批量启用用户
+// @Description 启用多个用户，任务异步执行（仅管理员）
+// @Tags 批量操作
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body bulkUsersRequest true "用户ID"
+// @Success 200 {object} Response "批量任务"
+// @Router /api/v1/admin/bulk/users/enable [post]
+func (c *BulkController) EnableUsers(ctx *gin.Context) {
+	c.changeStatus(ctx, 1)
+}
+
+// DeleteUsers 批量删除用户
+// @Summary 批量删除用户
+// @Description 删除多个用户并撤销其已签发的token，用户及关联数据进入回收站；有文章的用户和管理员账号记录为失败行，任务异步执行（仅管理员）
+// @Tags 批量操作
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body bulkUsersRequest true "用户ID"
+// @Success 200 {object} Response "批量任务"
+// @Router /api/v1/admin/bulk/users/delete [post]
+func (c *BulkController) DeleteUsers(ctx *gin.Context) {
+	operatorID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "用户未登录")
+		return
+	}
+	var req bulkUsersRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "请求参数无效: "+err.Error())
+		return
+	}
+
+	job, err := c.bulkService.SubmitUserDeletion(req.UserIDs, operatorID)
+	if err != nil {
+		c.submitError(ctx, err)
+		return
+	}
+	c.Success(ctx, job, "删除任务已提交")
+}
+
+func (c *BulkController) changeStatus(ctx *gin.Context, status int) {
+	operatorID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "用户未登录")
+		return
+	}
+	var req bulkUsersRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "请求参数无效: "+err.Error())
+		return
+	}
+
+	job, err := c.bulkService.SubmitStatusChange(req.UserIDs, status, operatorID)
+	if err != nil {
+		c.submitError(ctx, err)
+		return
+	}
+	if status == 0 {
+		c.Success(ctx, job, "禁用任务已提交")
+		return
+	}
+	c.Success(ctx, job, "启用任务已提交")
+}
+
+// submitError 参数错误返回400，其他错误返回500
+func (c *BulkController) submitError(ctx *gin.Context, err error) {
+	if errors.Is(err, Services.ErrBulkInvalidRole) || errors.Is(err, Services.ErrBulkInvalidStatus) ||
+		errors.Is(err, Services.ErrBulkJobEmpty) || errors.Is(err, Services.ErrBulkJobTooLarge) {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.Error(ctx, http.StatusInternalServerError, "提交批量任务失败: "+err.Error())
+}
+
+// GetJobs 获取批量任务列表
+// @Summary 获取批量任务列表
+// @Tags 批量操作
+// @Produce json
+// @Security ApiKeyAuth
+// @Param type query string false "任务类型(user_import/role_assign/user_status/user_delete)"
+// @Param status query string false "任务状态(queued/running/completed/failed)"
+// @Param page query int false "页码"
+// @Param limit query int false "每页数量"
+// @Success 200 {object} Response "批量任务列表"
+// @Router /api/v1/admin/bulk/jobs [get]
+func (c *BulkController) GetJobs(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	jobs, total, err := c.bulkService.ListJobs(Services.BulkJobFilter{
+		Type:   ctx.Query("type"),
+		Status: ctx.Query("status"),
+		Page:   page,
+		Limit:  limit,
+	})
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取批量任务失败: "+err.Error())
+		return
+	}
+
+	c.Success(ctx, gin.H{
+		"jobs":        jobs,
+		"total":       total,
+		"page":        page,
+		"limit":       limit,
+		"total_pages": (int(total) + limit - 1) / limit,
+	}, "批量任务列表获取成功")
+}
+
+// GetJob 获取批量任务进度
+// @Summary 获取批量任务进度
+// @Tags 批量操作
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "任务ID"
+// @Success 200 {object} Response "任务状态和进度"
+// @Router /api/v1/admin/bulk/jobs/{id} [get]
+func (c *BulkController) GetJob(ctx *gin.Context) {
+	job, ok := c.findJob(ctx)
+	if !ok {
+		return
+	}
+	c.Success(ctx, gin.H{
+		"job":      job,
+		"progress": job.Progress(),
+	}, "批量任务获取成功")
+}
+
+// GetJobErrors 获取批量任务的行级错误
+// @Summary 获取批量任务的行级错误
+// @Tags 批量操作
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "任务ID"
+// @Param page query int false "页码"
+// @Param limit query int false "每页数量"
+// @Success 200 {object} Response "错误明细"
+// @Router /api/v1/admin/bulk/jobs/{id}/errors [get]
+func (c *BulkController) GetJobErrors(ctx *gin.Context) {
+	job, ok := c.findJob(ctx)
+	if !ok {
+		return
+	}
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	rowErrors, total, err := c.bulkService.ListJobErrors(job.ID, page, limit)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取错误明细失败: "+err.Error())
+		return
+	}
+
+	c.Success(ctx, gin.H{
+		"errors":      rowErrors,
+		"total":       total,
+		"page":        page,
+		"limit":       limit,
+		"total_pages": (int(total) + limit - 1) / limit,
+	}, "错误明细获取成功")
+}
+
+func (c *BulkController) findJob(ctx *gin.Context) (*Models.BulkJob, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, "无效的任务ID")
+		return nil, false
+	}
+	job, err := c.bulkService.GetJob(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.NotFound(ctx, "批量任务不存在")
+		return nil, false
+	}
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取批量任务失败: "+err.Error())
+		return nil, false
+	}
+	return job, true
+}
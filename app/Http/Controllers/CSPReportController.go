@@ -0,0 +1,69 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Services"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CSPReportController CSP违规报告控制器
+//
+// 功能说明：
+// 1. 接收浏览器按 Content-Security-Policy 的 report-uri 指令上报的违规报告
+// 2. 兼容 application/csp-report 格式和 Reporting API 的 application/reports+json 格式
+// 3. 报告保存为 csp_violation 类型的安全事件
+//
+// 安全特性：
+// - 浏览器上报时不携带认证信息，接口不需要认证
+// - 请求体超过配置的大小时返回413，不读取剩余内容
+type CSPReportController struct {
+	Controller
+	reportService *Services.CSPReportService
+	maxBytes      int64
+}
+
+// NewCSPReportController 创建CSP违规报告控制器，maxBytes 为请求体的最大字节数，不大于0时为64KB
+func NewCSPReportController(reportService *Services.CSPReportService, maxBytes int64) *CSPReportController {
+	if maxBytes <= 0 {
+		maxBytes = 64 * 1024
+	}
+	return &CSPReportController{reportService: reportService, maxBytes: maxBytes}
+}
+
+// ReportCSPViolation 接收CSP违规报告
+// @Summary 接收CSP违规报告
+// @Description 浏览器自动上报，请求体为 {"csp-report": {...}} 或 Reporting API 的报告数组
+// @Tags 安全防护
+// @Accept json
+// @Success 204 "已接收"
+// @Failure 400 {object} Response "报告格式无效"
+// @Failure 413 {object} Response "请求体过大"
+// @Router /api/v1/csp-report [post]
+func (c *CSPReportController) ReportCSPViolation(ctx *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, c.maxBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.Error(ctx, http.StatusRequestEntityTooLarge, "CSP违规报告过大")
+			return
+		}
+		c.Error(ctx, http.StatusBadRequest, "读取CSP违规报告失败")
+		return
+	}
+
+	reports, err := Services.ParseCSPReports(body)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, err := c.reportService.Record(reports, ctx.ClientIP(), ctx.GetHeader("User-Agent")); err != nil {
+		log.Printf("保存CSP违规报告失败: %v", err)
+		c.Error(ctx, http.StatusInternalServerError, "保存CSP违规报告失败")
+		return
+	}
+	c.NoContent(ctx)
+}
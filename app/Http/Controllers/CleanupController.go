@@ -0,0 +1,120 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Services"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CleanupController 过期数据清理任务管理控制器
+type CleanupController struct {
+	Controller
+	scheduler *Services.CleanupScheduler
+}
+
+// NewCleanupController 创建过期数据清理任务管理控制器
+func NewCleanupController(scheduler *Services.CleanupScheduler) *CleanupController {
+	return &CleanupController{scheduler: scheduler}
+}
+
+// GetCleanupJobs 获取清理任务列表
+// @Summary 获取清理任务列表
+// @Description 列出已注册的清理任务及最近一次运行的进度和结果（仅管理员）
+// @Tags 数据清理
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "清理任务列表"
+// @Router /api/v1/admin/cleanup [get]
+func (c *CleanupController) GetCleanupJobs(ctx *gin.Context) {
+	c.Success(ctx, c.scheduler.Jobs(), "清理任务获取成功")
+}
+
+// GetCleanupJob 获取清理任务状态
+// @Summary 获取清理任务状态
+// @Tags 数据清理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param job path string true "任务名称"
+// @Success 200 {object} Response "清理任务状态"
+// @Failure 404 {object} Response "清理任务不存在"
+// @Router /api/v1/admin/cleanup/{job} [get]
+func (c *CleanupController) GetCleanupJob(ctx *gin.Context) {
+	status, err := c.scheduler.Job(ctx.Param("job"))
+	if err != nil {
+		c.cleanupError(ctx, err)
+		return
+	}
+	c.Success(ctx, status, "清理任务获取成功")
+}
+
+// RunCleanupJob 立即运行清理任务
+// @Summary 立即运行清理任务
+// @Description 在后台运行清理任务，通过任务状态查询进度（仅管理员）
+// @Tags 数据清理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param job path string true "任务名称"
+// @Success 202 {object} Response "清理任务已开始"
+// @Failure 404 {object} Response "清理任务不存在"
+// @Failure 409 {object} Response "清理任务正在运行"
+// @Router /api/v1/admin/cleanup/{job}/run [post]
+func (c *CleanupController) RunCleanupJob(ctx *gin.Context) {
+	name := ctx.Param("job")
+	status, err := c.scheduler.Trigger(name)
+	if err != nil {
+		c.cleanupError(ctx, err)
+		return
+	}
+	c.audit(ctx, "cleanup_run", name, fmt.Sprintf("手动运行清理任务 %s", name))
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": c.Trans(ctx, "清理任务已开始"),
+		"data":    status,
+	})
+}
+
+// CancelCleanupJob 取消正在运行的清理任务
+// @Summary 取消正在运行的清理任务
+// @Description 当前批次完成后停止，已删除的批次不回滚（仅管理员）
+// @Tags 数据清理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param job path string true "任务名称"
+// @Success 200 {object} Response "已取消"
+// @Failure 404 {object} Response "清理任务不存在"
+// @Failure 409 {object} Response "清理任务未在运行"
+// @Router /api/v1/admin/cleanup/{job}/cancel [post]
+func (c *CleanupController) CancelCleanupJob(ctx *gin.Context) {
+	name := ctx.Param("job")
+	if err := c.scheduler.Cancel(name); err != nil {
+		c.cleanupError(ctx, err)
+		return
+	}
+	c.audit(ctx, "cleanup_cancel", name, fmt.Sprintf("取消清理任务 %s", name))
+	c.Success(ctx, gin.H{"job": name}, "清理任务已取消")
+}
+
+// audit 记录清理任务操作的审计日志，记录失败不影响操作结果
+func (c *CleanupController) audit(ctx *gin.Context, action, name, description string) {
+	if Database.DB == nil {
+		return
+	}
+	userID, _ := c.GetCurrentUser(ctx)
+	Services.NewAuditService(Database.DB).LogUserAction(nil, userID, ctx.GetString("username"), action, "cleanup_job:"+name, 0, description)
+}
+
+// cleanupError 清理任务错误响应
+func (c *CleanupController) cleanupError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, Services.ErrCleanupJobNotFound):
+		c.Error(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, Services.ErrCleanupJobRunning), errors.Is(err, Services.ErrCleanupJobNotRunning):
+		c.Error(ctx, http.StatusConflict, err.Error())
+	default:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+}
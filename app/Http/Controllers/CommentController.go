@@ -0,0 +1,206 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Utils"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CommentController 评论控制器
+//
+// 功能说明：
+// 1. 查询和发表告警、安全事件和监控报告的评论，支持回复评论
+// 2. 作者编辑自己的评论，作者和管理员删除评论
+//
+// 安全特性：
+// - 所有接口都需要登录（在路由中配置）
+// - 监控报告的评论只有管理员可以查看和发表
+// - 编辑和删除记录审计日志
+type CommentController struct {
+	Controller
+	commentService *Services.CommentService
+}
+
+// CreateCommentRequest 发表评论请求
+type CreateCommentRequest struct {
+	ResourceType string `json:"resource_type" binding:"required"`
+	ResourceID   string `json:"resource_id" binding:"required"`
+	ParentID     *uint  `json:"parent_id"`
+	Content      string `json:"content" binding:"required"`
+}
+
+// UpdateCommentRequest 编辑评论请求
+type UpdateCommentRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// NewCommentController 创建评论控制器
+func NewCommentController(commentService *Services.CommentService) *CommentController {
+	return &CommentController{commentService: commentService}
+}
+
+// ListComments 获取资源的评论
+// @Summary 获取评论
+// @Description 获取告警、安全事件或监控报告的评论线程，回复挂在顶层评论下
+// @Tags 评论
+// @Produce json
+// @Security ApiKeyAuth
+// @Param resource_type query string true "资源类型(alert/security_event/report)"
+// @Param resource_id query string true "资源ID"
+// @Success 200 {object} Response "评论线程"
+// @Failure 400 {object} Response "资源类型无效"
+// @Failure 403 {object} Response "无权查看"
+// @Router /api/v1/comments [get]
+func (c *CommentController) ListComments(ctx *gin.Context) {
+	actor, ok := c.actor(ctx)
+	if !ok {
+		return
+	}
+	resourceID := ctx.Query("resource_id")
+	if resourceID == "" {
+		c.Error(ctx, http.StatusBadRequest, "缺少资源ID")
+		return
+	}
+	threads, err := c.commentService.List(actor, ctx.Query("resource_type"), resourceID)
+	if err != nil {
+		c.commentError(ctx, err)
+		return
+	}
+	c.Success(ctx, threads, "评论获取成功")
+}
+
+// CreateComment 发表评论
+// @Summary 发表评论
+// @Description 发表评论或回复评论，内容中的 @用户名 会通知被提及的用户
+// @Tags 评论
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param comment body CreateCommentRequest true "评论"
+// @Success 201 {object} Response "创建的评论"
+// @Failure 400 {object} Response "参数无效"
+// @Failure 404 {object} Response "资源或回复的评论不存在"
+// @Router /api/v1/comments [post]
+func (c *CommentController) CreateComment(ctx *gin.Context) {
+	actor, ok := c.actor(ctx)
+	if !ok {
+		return
+	}
+	var req CreateCommentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	comment, err := c.commentService.Create(actor, req.ResourceType, req.ResourceID, req.ParentID, req.Content)
+	if err != nil {
+		c.commentError(ctx, err)
+		return
+	}
+	c.Created(ctx, comment, "评论已发表")
+}
+
+// UpdateComment 编辑评论
+// @Summary 编辑评论
+// @Description 作者编辑自己的评论，只通知新增提及的用户
+// @Tags 评论
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "评论ID"
+// @Param comment body UpdateCommentRequest true "评论内容"
+// @Success 200 {object} Response "编辑后的评论"
+// @Failure 403 {object} Response "不是评论作者"
+// @Failure 404 {object} Response "评论不存在"
+// @Router /api/v1/comments/{id} [put]
+func (c *CommentController) UpdateComment(ctx *gin.Context) {
+	actor, ok := c.actor(ctx)
+	if !ok {
+		return
+	}
+	id, ok := c.pathID(ctx)
+	if !ok {
+		return
+	}
+	var req UpdateCommentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	comment, err := c.commentService.Update(id, actor, req.Content)
+	if err != nil {
+		c.commentError(ctx, err)
+		return
+	}
+	c.Success(ctx, comment, "评论已更新")
+}
+
+// DeleteComment 删除评论
+// @Summary 删除评论
+// @Description 作者或管理员删除评论，仍有回复的评论在线程中保留占位
+// @Tags 评论
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "评论ID"
+// @Success 200 {object} Response "删除成功"
+// @Failure 403 {object} Response "无权删除"
+// @Failure 404 {object} Response "评论不存在"
+// @Router /api/v1/comments/{id} [delete]
+func (c *CommentController) DeleteComment(ctx *gin.Context) {
+	actor, ok := c.actor(ctx)
+	if !ok {
+		return
+	}
+	id, ok := c.pathID(ctx)
+	if !ok {
+		return
+	}
+	if err := c.commentService.Delete(id, actor); err != nil {
+		c.commentError(ctx, err)
+		return
+	}
+	c.Success(ctx, nil, "评论已删除")
+}
+
+// actor 获取当前操作者
+func (c *CommentController) actor(ctx *gin.Context) (Services.CommentActor, bool) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "需要登录")
+		return Services.CommentActor{}, false
+	}
+	return Services.CommentActor{
+		UserID:        userID,
+		IsAdmin:       c.IsAdmin(ctx),
+		IPAddress:     ctx.ClientIP(),
+		RequestID:     ctx.GetString(Utils.RequestIDKey),
+		CorrelationID: ctx.GetString(Utils.CorrelationIDKey),
+	}, true
+}
+
+// pathID 解析路径中的评论ID
+func (c *CommentController) pathID(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		c.Error(ctx, http.StatusBadRequest, "无效的评论ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// commentError 按错误类型返回响应
+func (c *CommentController) commentError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, Services.ErrCommentNotFound), errors.Is(err, Services.ErrCommentResourceNotFound):
+		c.Error(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, Services.ErrCommentForbidden):
+		c.Error(ctx, http.StatusForbidden, err.Error())
+	case errors.Is(err, Services.ErrInvalidComment), errors.Is(err, Services.ErrInvalidCommentResource):
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+	default:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,250 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Services"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConfigSnapshotController 配置快照控制器
+//
+// 功能说明：
+// 1. 查看当前脱敏后的有效配置
+// 2. 按环境生成带版本号的配置快照，导入其他环境的快照
+// 3. 比较两个快照或快照与当前配置，检测部署之间和环境之间的配置漂移
+//
+// 安全特性：
+// - 所有接口需要管理员权限（在路由中配置）
+// - 密码、密钥、令牌和URL中的密码在返回和保存前脱敏，导入的快照同样脱敏
+type ConfigSnapshotController struct {
+	Controller
+	snapshotService *Services.ConfigSnapshotService
+}
+
+// NewConfigSnapshotController 创建配置快照控制器
+func NewConfigSnapshotController(snapshotService *Services.ConfigSnapshotService) *ConfigSnapshotController {
+	return &ConfigSnapshotController{snapshotService: snapshotService}
+}
+
+// ConfigSnapshotQuery 快照列表查询参数
+type ConfigSnapshotQuery struct {
+	Environment string `form:"environment"` // 环境，为空时返回全部环境
+	Limit       int    `form:"limit"`       // 返回条数，默认50，最多200
+}
+
+// ConfigDiffQuery 快照差异查询参数
+type ConfigDiffQuery struct {
+	To     uint   `form:"to"`     // 比较的快照ID，为空时与当前配置比较
+	Ignore string `form:"ignore"` // 忽略的键前缀，逗号分隔，如 server.port,log
+}
+
+// GetEffectiveConfig 获取当前有效配置
+// @Summary 获取当前有效配置
+// @Description 配置展开为 section.key 形式，敏感配置已脱敏
+// @Tags 配置管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "脱敏后的有效配置"
+// @Router /api/v1/admin/config/effective [get]
+func (c *ConfigSnapshotController) GetEffectiveConfig(ctx *gin.Context) {
+	values, err := c.snapshotService.CurrentValues()
+	if err != nil {
+		c.snapshotError(ctx, err)
+		return
+	}
+	c.Success(ctx, values, "有效配置获取成功")
+}
+
+// GetConfigSnapshots 获取配置快照列表
+// @Summary 获取配置快照列表
+// @Tags 配置管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param environment query string false "环境"
+// @Param limit query int false "返回条数，默认50，最多200"
+// @Success 200 {object} Response "快照列表，不包含配置内容"
+// @Router /api/v1/admin/config/snapshots [get]
+func (c *ConfigSnapshotController) GetConfigSnapshots(ctx *gin.Context) {
+	var query ConfigSnapshotQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	snapshots, err := c.snapshotService.List(query.Environment, query.Limit)
+	if err != nil {
+		c.snapshotError(ctx, err)
+		return
+	}
+	c.Success(ctx, snapshots, "配置快照列表获取成功")
+}
+
+// CreateConfigSnapshot 为当前配置生成快照
+// @Summary 为当前配置生成快照
+// @Description 版本号在同一环境内递增，environment 为空时使用 APP_ENV
+// @Tags 配置管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param snapshot body Services.ConfigSnapshotInput false "环境和说明"
+// @Success 201 {object} Response "生成的快照"
+// @Failure 400 {object} Response "参数无效"
+// @Router /api/v1/admin/config/snapshots [post]
+func (c *ConfigSnapshotController) CreateConfigSnapshot(ctx *gin.Context) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "需要登录")
+		return
+	}
+	var input Services.ConfigSnapshotInput
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&input); err != nil {
+			c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+			return
+		}
+	}
+	snapshot, err := c.snapshotService.Capture(input, userID)
+	if err != nil {
+		c.snapshotError(ctx, err)
+		return
+	}
+	c.Created(ctx, snapshot, "配置快照已生成")
+}
+
+// ImportConfigSnapshot 导入其他环境的配置快照
+// @Summary 导入其他环境的配置快照
+// @Description values 为其他环境 GET /api/v1/admin/config/effective 或快照详情返回的配置，导入时再次脱敏
+// @Tags 配置管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param snapshot body Services.ConfigSnapshotInput true "环境和配置"
+// @Success 201 {object} Response "导入的快照"
+// @Failure 400 {object} Response "参数无效"
+// @Router /api/v1/admin/config/snapshots/import [post]
+func (c *ConfigSnapshotController) ImportConfigSnapshot(ctx *gin.Context) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "需要登录")
+		return
+	}
+	var input Services.ConfigSnapshotInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	snapshot, err := c.snapshotService.Import(input, userID)
+	if err != nil {
+		c.snapshotError(ctx, err)
+		return
+	}
+	c.Created(ctx, snapshot, "配置快照已导入")
+}
+
+// GetConfigSnapshot 获取配置快照
+// @Summary 获取配置快照
+// @Tags 配置管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "快照ID"
+// @Success 200 {object} Response "快照及其配置"
+// @Failure 404 {object} Response "快照不存在"
+// @Router /api/v1/admin/config/snapshots/{id} [get]
+func (c *ConfigSnapshotController) GetConfigSnapshot(ctx *gin.Context) {
+	id, ok := c.snapshotID(ctx)
+	if !ok {
+		return
+	}
+	snapshot, err := c.snapshotService.Get(id)
+	if err != nil {
+		c.snapshotError(ctx, err)
+		return
+	}
+	c.Success(ctx, snapshot, "配置快照获取成功")
+}
+
+// DeleteConfigSnapshot 删除配置快照
+// @Summary 删除配置快照
+// @Tags 配置管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "快照ID"
+// @Success 200 {object} Response "删除成功"
+// @Failure 404 {object} Response "快照不存在"
+// @Router /api/v1/admin/config/snapshots/{id} [delete]
+func (c *ConfigSnapshotController) DeleteConfigSnapshot(ctx *gin.Context) {
+	id, ok := c.snapshotID(ctx)
+	if !ok {
+		return
+	}
+	if err := c.snapshotService.Delete(id); err != nil {
+		c.snapshotError(ctx, err)
+		return
+	}
+	c.Success(ctx, nil, "配置快照已删除")
+}
+
+// DiffConfigSnapshot 比较配置快照
+// @Summary 比较配置快照
+// @Description 比较快照与 to 指定的快照，to 为空时与当前配置比较；敏感配置只比较是否设置
+// @Tags 配置管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "快照ID"
+// @Param to query int false "比较的快照ID"
+// @Param ignore query string false "忽略的键前缀，逗号分隔"
+// @Success 200 {object} Response "差异，按键排序"
+// @Failure 404 {object} Response "快照不存在"
+// @Router /api/v1/admin/config/snapshots/{id}/diff [get]
+func (c *ConfigSnapshotController) DiffConfigSnapshot(ctx *gin.Context) {
+	id, ok := c.snapshotID(ctx)
+	if !ok {
+		return
+	}
+	var query ConfigDiffQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	var ignore []string
+	for _, prefix := range strings.Split(query.Ignore, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			ignore = append(ignore, prefix)
+		}
+	}
+	diff, err := c.snapshotService.Diff(id, query.To, ignore)
+	if err != nil {
+		c.snapshotError(ctx, err)
+		return
+	}
+	c.Success(ctx, diff, "配置差异获取成功")
+}
+
+// snapshotID 解析路径中的快照ID
+func (c *ConfigSnapshotController) snapshotID(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		c.Error(ctx, http.StatusBadRequest, "无效的快照ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// snapshotError 按错误类型返回状态码
+func (c *ConfigSnapshotController) snapshotError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, Services.ErrConfigSnapshotNotFound):
+		c.Error(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, Services.ErrInvalidConfigSnapshot):
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+	case errors.Is(err, Services.ErrConfigUnavailable):
+		c.Error(ctx, http.StatusServiceUnavailable, err.Error())
+	default:
+		log.Printf("配置快照操作失败: %v", err)
+		c.Error(ctx, http.StatusInternalServerError, "配置快照操作失败")
+	}
+}
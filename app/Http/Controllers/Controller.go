@@ -1,9 +1,13 @@
 package Controllers
 
 import (
+	"cloud-platform-api/app/I18n"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Utils"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -62,8 +66,8 @@ type Controller struct {
 // - data可以是任何类型（对象、数组、字符串等）
 func (c *Controller) Success(ctx *gin.Context, data interface{}, message string) {
 	ctx.JSON(http.StatusOK, gin.H{
-		"success": true,  // 操作成功标志
-		"message": message, // 成功消息
+		"success": true,                  // 操作成功标志
+		"message": c.Trans(ctx, message), // 成功消息（支持翻译键）
 		"data":    data,    // 返回的数据
 	})
 }
@@ -100,6 +104,7 @@ func (c *Controller) Success(ctx *gin.Context, data interface{}, message string)
 // - message应该用户友好，避免泄露系统信息
 // - 生产环境不应该返回详细的错误堆栈
 func (c *Controller) Error(ctx *gin.Context, statusCode int, message string) {
+	message = c.Trans(ctx, message)
 	ctx.JSON(statusCode, gin.H{
 		"success": false,  // 操作失败标志
 		"message": message, // 错误消息
@@ -135,9 +140,14 @@ func (c *Controller) Error(ctx *gin.Context, statusCode int, message string) {
 // - errors应该包含具体的验证错误信息
 // - 错误信息应该用户友好，便于前端显示
 func (c *Controller) ValidationError(ctx *gin.Context, errors interface{}) {
+	// 字符串形式的错误视为翻译键
+	if key, ok := errors.(string); ok {
+		errors = c.Trans(ctx, key)
+	}
+
 	ctx.JSON(http.StatusBadRequest, gin.H{
 		"success": false,
-		"message": "Validation failed",
+		"message": c.Trans(ctx, "common.validation_failed"),
 		"errors":  errors,
 	})
 }
@@ -164,7 +174,7 @@ func (c *Controller) ValidationError(ctx *gin.Context, errors interface{}) {
 // - 不应该泄露系统内部信息
 func (c *Controller) NotFound(ctx *gin.Context, message string) {
 	if message == "" {
-		message = "Resource not found"
+		message = "common.not_found"
 	}
 	c.Error(ctx, http.StatusNotFound, message)
 }
@@ -190,7 +200,7 @@ func (c *Controller) NotFound(ctx *gin.Context, message string) {
 // - 不应该泄露认证失败的具体原因
 func (c *Controller) Unauthorized(ctx *gin.Context, message string) {
 	if message == "" {
-		message = "Unauthorized"
+		message = "common.unauthorized"
 	}
 	c.Error(ctx, http.StatusUnauthorized, message)
 }
@@ -216,7 +226,7 @@ func (c *Controller) Unauthorized(ctx *gin.Context, message string) {
 // - 不应该泄露权限系统的详细信息
 func (c *Controller) Forbidden(ctx *gin.Context, message string) {
 	if message == "" {
-		message = "Forbidden"
+		message = "common.forbidden"
 	}
 	c.Error(ctx, http.StatusForbidden, message)
 }
@@ -243,7 +253,7 @@ func (c *Controller) Forbidden(ctx *gin.Context, message string) {
 // - 用户看到的消息应该友好，不泄露技术细节
 func (c *Controller) ServerError(ctx *gin.Context, message string) {
 	if message == "" {
-		message = "Internal server error"
+		message = "common.server_error"
 	}
 	c.Error(ctx, http.StatusInternalServerError, message)
 }
@@ -281,11 +291,55 @@ func (c *Controller) TooManyRequests(ctx *gin.Context, message string, retryAfte
 	ctx.Header("Retry-After", strconv.Itoa(retryAfter))
 	ctx.JSON(http.StatusTooManyRequests, gin.H{
 		"success":     false,
-		"message":     message,
+		"message":     c.Trans(ctx, message),
 		"retry_after": retryAfter,
 	})
 }
 
+// SetETag 设置资源版本的ETag响应头
+//
+// ETag 格式为带引号的版本号，如 "3"，更新时通过 If-Match 请求头原样传回
+func (c *Controller) SetETag(ctx *gin.Context, version uint) {
+	ctx.Header("ETag", strconv.Quote(strconv.FormatUint(uint64(version), 10)))
+}
+
+// ExpectedVersion 获取客户端期望的资源版本
+//
+// 功能说明：
+// 1. 优先使用 If-Match 请求头，支持 "3"、W/"3" 和 3 三种写法
+// 2. 未携带 If-Match 或为 * 时使用请求体中的 version 字段
+// 3. 返回 0 表示客户端没有指定版本，由服务端按加载时的版本检查
+// 4. If-Match 格式错误时返回400并返回 false
+func (c *Controller) ExpectedVersion(ctx *gin.Context, bodyVersion uint) (uint, bool) {
+	ifMatch := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return bodyVersion, true
+	}
+	value := strings.Trim(strings.TrimPrefix(ifMatch, "W/"), `"`)
+	version, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || version == 0 {
+		c.Error(ctx, http.StatusBadRequest, "common.invalid_if_match")
+		return 0, false
+	}
+	return uint(version), true
+}
+
+// VersionConflict 版本冲突响应
+//
+// 使用409 Conflict状态码，响应体的 current_version 和 ETag 响应头为数据库中的当前版本，
+// 客户端应重新获取资源并在合并修改后重试
+func (c *Controller) VersionConflict(ctx *gin.Context, conflict *Models.VersionConflictError) {
+	c.SetETag(ctx, conflict.Current)
+	message := c.Trans(ctx, "common.version_conflict")
+	ctx.JSON(http.StatusConflict, gin.H{
+		"success":          false,
+		"message":          message,
+		"error":            message,
+		"current_version":  conflict.Current,
+		"expected_version": conflict.Expected,
+	})
+}
+
 // PaginatedSuccess 分页成功响应
 //
 // 功能说明：
@@ -331,7 +385,7 @@ func (c *Controller) PaginatedSuccess(ctx *gin.Context, data interface{}, total
 	
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": message,
+		"message": c.Trans(ctx, message),
 		"data":    data,
 		"meta": gin.H{
 			"total":       total,
@@ -342,6 +396,34 @@ func (c *Controller) PaginatedSuccess(ctx *gin.Context, data interface{}, total
 	})
 }
 
+// ListSuccess 列表成功响应
+//
+// 功能说明：
+// 1. 返回列表数据的统一格式，页码分页和游标分页共用
+// 2. meta.cursor 为下一页游标，meta.has_more 表示是否还有数据
+// 3. meta.total 和 meta.total_pages 只在统计了总数时返回
+//
+// 响应格式：
+// {
+//   "success": true,
+//   "message": "查询成功",
+//   "data": [...],
+//   "meta": {
+//     "mode": "cursor",
+//     "page_size": 20,
+//     "cursor": "eyJ0Ijoi...",
+//     "has_more": true
+//   }
+// }
+func (c *Controller) ListSuccess(ctx *gin.Context, data interface{}, meta Utils.PageMeta, message string) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": c.Trans(ctx, message),
+		"data":    data,
+		"meta":    meta,
+	})
+}
+
 // Created 创建成功响应
 //
 // 功能说明：
@@ -376,7 +458,7 @@ func (c *Controller) Created(ctx *gin.Context, data interface{}, message string)
 	}
 	ctx.JSON(http.StatusCreated, gin.H{
 		"success": true,
-		"message": message,
+		"message": c.Trans(ctx, message),
 		"data":    data,
 	})
 }
@@ -574,3 +656,105 @@ func (c *Controller) ValidatePagination(ctx *gin.Context) (page, pageSize int) {
 
 	return page, pageSize
 }
+
+// Locale 获取当前请求的语言
+//
+// 功能说明：
+// 1. 显式指定的语言（查询参数或X-Locale请求头）优先
+// 2. 已认证用户使用其偏好语言
+// 3. 否则使用语言中间件根据Accept-Language解析的结果
+// 4. 未经过语言中间件时（如单元测试）直接按请求头解析
+//
+// 用户偏好语言每个请求只查询一次，结果保存在请求上下文中
+func (c *Controller) Locale(ctx *gin.Context) string {
+	if ctx.GetBool("locale_explicit") {
+		return ctx.GetString("locale")
+	}
+
+	if preference := c.userLocalePreference(ctx); preference != "" {
+		if locale := I18n.MatchLocale([]string{preference}, I18n.GetTranslator().Locales()); locale != "" {
+			return locale
+		}
+	}
+
+	if locale := ctx.GetString("locale"); locale != "" {
+		return locale
+	}
+
+	return I18n.Negotiate(I18n.LocaleRequest{
+		Explicit:       ctx.GetHeader("X-Locale"),
+		AcceptLanguage: ctx.GetHeader("Accept-Language"),
+	}, nil)
+}
+
+// userLocalePreference 获取当前用户的偏好语言，同一请求内只查询一次
+func (c *Controller) userLocalePreference(ctx *gin.Context) string {
+	userID := ctx.GetString("user_id")
+	if userID == "" {
+		return ""
+	}
+	if preference, ok := ctx.Get("locale_preference"); ok {
+		return preference.(string)
+	}
+	preference := I18n.UserPreference(userID)
+	ctx.Set("locale_preference", preference)
+	return preference
+}
+
+// Trans 按当前请求语言翻译消息键
+//
+// 找不到翻译时原样返回，因此也可以传入普通文本。
+func (c *Controller) Trans(ctx *gin.Context, key string, params ...I18n.Params) string {
+	if key == "" {
+		return key
+	}
+	return I18n.T(c.Locale(ctx), key, params...)
+}
+
+// TransChoice 按当前请求语言翻译复数消息
+func (c *Controller) TransChoice(ctx *gin.Context, key string, count int64, params ...I18n.Params) string {
+	return I18n.Choice(c.Locale(ctx), key, count, params...)
+}
+
+// TransError 按当前请求语言输出错误消息（支持I18n.Error）
+func (c *Controller) TransError(ctx *gin.Context, err error) string {
+	return I18n.LocalizeError(c.Locale(ctx), err)
+}
+
+// ParsePageRequest 解析列表分页参数
+//
+// 功能说明：
+// 1. 每页数量取 limit 或 page_size，默认20，最大100
+// 2. 传入 cursor 或 pagination=cursor 时使用游标分页，否则按 page 页码分页
+// 3. 页码分页默认统计总数，可用 with_total=false 关闭；游标分页默认不统计，可用 with_total=true 开启
+// 4. 游标格式错误时返回 Utils.ErrInvalidCursor
+func (c *Controller) ParsePageRequest(ctx *gin.Context) (Utils.PageRequest, error) {
+	limitStr := ctx.Query("limit")
+	if limitStr == "" {
+		limitStr = ctx.DefaultQuery("page_size", "20")
+	}
+	limit, _ := strconv.Atoi(limitStr)
+	if limit < 1 {
+		limit = 20
+	}
+	limit = min(limit, 100)
+
+	req := Utils.PageRequest{Mode: Utils.PageModeOffset, Limit: limit, Cursor: ctx.Query("cursor")}
+	if req.Cursor != "" || ctx.Query("pagination") == Utils.PageModeCursor {
+		req.Mode = Utils.PageModeCursor
+		if req.Cursor != "" {
+			if _, _, err := Utils.DecodeCursor(req.Cursor); err != nil {
+				return req, err
+			}
+		}
+	} else {
+		req.Page, _ = strconv.Atoi(ctx.DefaultQuery("page", "1"))
+		req.Page = max(req.Page, 1)
+	}
+
+	req.WithTotal = req.Mode == Utils.PageModeOffset
+	if withTotal, err := strconv.ParseBool(ctx.Query("with_total")); err == nil {
+		req.WithTotal = withTotal
+	}
+	return req, nil
+}
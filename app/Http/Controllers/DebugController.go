@@ -0,0 +1,66 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DebugController 请求调试记录控制器
+type DebugController struct {
+	Controller
+	store *Services.DebugRequestStore
+}
+
+// NewDebugController 创建请求调试记录控制器
+func NewDebugController(store *Services.DebugRequestStore) *DebugController {
+	return &DebugController{store: store}
+}
+
+// DebugClearResponse 清空调试记录的响应
+type DebugClearResponse struct {
+	Removed int `json:"removed"`
+}
+
+// ListRequests 获取调试记录摘要
+// @Summary 获取调试记录摘要
+// @Description 按时间从新到旧返回当前实例保存的调试记录（仅管理员）
+// @Tags 请求调试
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "调试记录摘要"
+// @Router /api/v1/admin/debug/requests [get]
+func (c *DebugController) ListRequests(ctx *gin.Context) {
+	c.Success(ctx, c.store.List(), "调试记录获取成功")
+}
+
+// GetRequest 获取请求的调试记录
+// @Summary 获取请求的调试记录
+// @Description 返回请求执行的SQL、缓存操作和出站HTTP调用（仅管理员）
+// @Tags 请求调试
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request_id path string true "请求ID（响应头 X-Debug-Id）"
+// @Success 200 {object} Response "调试记录"
+// @Failure 404 {object} Response "记录不存在或已过期"
+// @Router /api/v1/admin/debug/requests/{request_id} [get]
+func (c *DebugController) GetRequest(ctx *gin.Context) {
+	request, ok := c.store.Get(ctx.Param("request_id"))
+	if !ok {
+		c.Error(ctx, http.StatusNotFound, "调试记录不存在或已过期")
+		return
+	}
+	c.Success(ctx, request, "调试记录获取成功")
+}
+
+// ClearRequests 清空调试记录
+// @Summary 清空调试记录
+// @Tags 请求调试
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "清除的数量"
+// @Router /api/v1/admin/debug/requests [delete]
+func (c *DebugController) ClearRequests(ctx *gin.Context) {
+	c.Success(ctx, DebugClearResponse{Removed: c.store.Clear()}, "调试记录已清空")
+}
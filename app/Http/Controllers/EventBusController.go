@@ -0,0 +1,136 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Utils"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EventBusController 领域事件总线管理控制器
+type EventBusController struct {
+	Controller
+	eventBus *Services.EventBus
+}
+
+// NewEventBusController 创建事件总线管理控制器
+func NewEventBusController(eventBus *Services.EventBus) *EventBusController {
+	return &EventBusController{eventBus: eventBus}
+}
+
+// OutboxEventQuerySpec outbox 事件列表可筛选、排序和返回的字段
+var OutboxEventQuerySpec = Utils.QuerySpec{
+	Fields: map[string]Utils.QueryField{
+		"id":              {Column: "id", Type: Utils.QueryInt, Filter: true, Sort: true},
+		"event_id":        {Column: "event_id", Type: Utils.QueryString, Filter: true},
+		"event_type":      {Column: "event_type", Type: Utils.QueryString, Filter: true, Sort: true},
+		"aggregate_type":  {Column: "aggregate_type", Type: Utils.QueryString, Filter: true},
+		"aggregate_id":    {Column: "aggregate_id", Type: Utils.QueryString, Filter: true},
+		"payload":         {Column: "payload", Type: Utils.QueryString},
+		"status":          {Column: "status", Type: Utils.QueryString, Filter: true},
+		"attempts":        {Column: "attempts", Type: Utils.QueryInt, Filter: true, Sort: true},
+		"next_attempt_at": {Column: "next_attempt_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+		"delivered":       {Column: "delivered", Type: Utils.QueryString},
+		"last_error":      {Column: "last_error", Type: Utils.QueryString},
+		"dispatched_at":   {Column: "dispatched_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+		"created_at":      {Column: "created_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+	},
+	DefaultSort: "-created_at",
+}
+
+// GetEvents 获取领域事件列表
+// @Summary 获取领域事件列表
+// @Description 分页查询 outbox 事件及其投递状态，支持 filter[status][eq]=failed 等筛选（仅管理员）
+// @Tags 事件总线
+// @Produce json
+// @Security ApiKeyAuth
+// @Param filter[status][eq] query string false "按状态筛选(pending/dispatching/dispatched/failed)"
+// @Param sort query string false "排序字段，逗号分隔，前缀-表示降序" default(-created_at)
+// @Param fields query string false "返回字段，逗号分隔"
+// @Param page query int false "页码" default(1)
+// @Param limit query int false "每页数量" default(20)
+// @Param cursor query string false "上一页返回的 meta.cursor，传入时使用游标分页"
+// @Success 200 {object} Response "事件列表"
+// @Failure 400 {object} Response "查询参数无效"
+// @Router /api/v1/admin/events [get]
+func (c *EventBusController) GetEvents(ctx *gin.Context) {
+	req, err := c.ParsePageRequest(ctx)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	q, err := Utils.ParseListQuery(ctx.Request.URL.Query(), OutboxEventQuerySpec)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	events, meta, err := c.eventBus.ListEvents(q, req)
+	if errors.Is(err, Utils.ErrInvalidCursor) || errors.Is(err, Utils.ErrInvalidQuery) {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取事件列表失败: "+err.Error())
+		return
+	}
+
+	data, err := q.Project(events)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取事件列表失败: "+err.Error())
+		return
+	}
+	c.ListSuccess(ctx, data, meta, "事件列表获取成功")
+}
+
+// GetStats 获取事件总线状态
+// @Summary 获取事件总线状态
+// @Description 返回各状态的事件数、启用的外部消息系统和进程内订阅（仅管理员）
+// @Tags 事件总线
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "事件总线状态"
+// @Router /api/v1/admin/events/stats [get]
+func (c *EventBusController) GetStats(ctx *gin.Context) {
+	stats, err := c.eventBus.Stats()
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取事件统计失败: "+err.Error())
+		return
+	}
+	c.Success(ctx, gin.H{
+		"stats":         stats,
+		"brokers":       c.eventBus.Brokers(),
+		"subscriptions": c.eventBus.Subscriptions(),
+	}, "事件总线状态获取成功")
+}
+
+// RetryEvent 重新投递失败的事件
+// @Summary 重新投递失败的事件
+// @Description 将失败的事件重置为待投递，已成功投递的目标不会重复投递（仅管理员）
+// @Tags 事件总线
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "事件ID"
+// @Success 200 {object} Response "已重新加入投递队列"
+// @Failure 400 {object} Response "事件不是失败状态"
+// @Failure 404 {object} Response "事件不存在"
+// @Router /api/v1/admin/events/{id}/retry [post]
+func (c *EventBusController) RetryEvent(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, "无效的事件ID")
+		return
+	}
+	if err := c.eventBus.Retry(uint(id)); err != nil {
+		if errors.Is(err, Services.ErrOutboxEventNotFound) {
+			c.NotFound(ctx, err.Error())
+			return
+		}
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.Success(ctx, gin.H{"id": id}, "事件已重新加入投递队列")
+}
@@ -0,0 +1,309 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Services"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportController 数据导出控制器
+//
+// 功能说明：
+// 1. 告警、登录尝试和安全事件导出为CSV或XLSX，筛选参数与对应的列表接口相同
+// 2. 导出文件边查询边写入响应，导出行数和是否截断在响应的 Trailer 中返回
+// 3. 管理员管理定时导出，定时导出的结果作为邮件附件发送
+//
+// 安全特性：
+// - 所有接口都需要登录（在路由中配置）
+// - 定时导出需要管理员权限（在路由中配置）
+// - CSV中以公式字符开头的文本加单引号前缀，防止表格软件执行公式
+type ExportController struct {
+	Controller
+	exportService *Services.DataExportService
+}
+
+// NewExportController 创建数据导出控制器
+func NewExportController(exportService *Services.DataExportService) *ExportController {
+	return &ExportController{exportService: exportService}
+}
+
+// ExportAlerts 导出监控告警
+// @Summary 导出监控告警
+// @Description 按告警列表的筛选参数导出CSV或XLSX，最后一列为告警的评论记录；与告警列表相同，只导出当前角色可以查看其指标的告警
+// @Tags 数据导出
+// @Produce application/octet-stream
+// @Security ApiKeyAuth
+// @Param format query string false "导出格式" Enums(csv,xlsx) default(csv)
+// @Param status query string false "告警状态"
+// @Param severity query string false "严重程度"
+// @Param since query string false "开始时间，RFC3339时间或时长（如24h）"
+// @Param fields query string false "导出字段，逗号分隔"
+// @Success 200 {file} file "导出文件"
+// @Failure 400 {object} Response "参数无效"
+// @Router /api/v1/monitoring/alerts/export [get]
+func (c *ExportController) ExportAlerts(ctx *gin.Context) {
+	c.export(ctx, Services.ExportDatasetAlerts)
+}
+
+// ExportSecurityEvents 导出安全事件
+// @Summary 导出安全事件
+// @Description 按安全事件列表的筛选、排序和字段参数导出CSV或XLSX；启用归档时只导出在线数据
+// @Tags 数据导出
+// @Produce application/octet-stream
+// @Security ApiKeyAuth
+// @Param format query string false "导出格式" Enums(csv,xlsx) default(csv)
+// @Param since query string false "开始时间，RFC3339时间或时长（如24h）"
+// @Param sort query string false "排序字段，如 -created_at"
+// @Param fields query string false "导出字段，逗号分隔"
+// @Success 200 {file} file "导出文件"
+// @Failure 400 {object} Response "参数无效"
+// @Router /api/v1/security/events/export [get]
+func (c *ExportController) ExportSecurityEvents(ctx *gin.Context) {
+	c.export(ctx, Services.ExportDatasetSecurityEvents)
+}
+
+// ExportLoginAttempts 导出登录尝试记录
+// @Summary 导出登录尝试记录
+// @Description 按登录尝试列表的筛选、排序和字段参数导出CSV或XLSX；启用归档时只导出在线数据
+// @Tags 数据导出
+// @Produce application/octet-stream
+// @Security ApiKeyAuth
+// @Param format query string false "导出格式" Enums(csv,xlsx) default(csv)
+// @Param since query string false "开始时间，RFC3339时间或时长（如24h）"
+// @Param sort query string false "排序字段，如 -attempt_time"
+// @Param fields query string false "导出字段，逗号分隔"
+// @Success 200 {file} file "导出文件"
+// @Failure 400 {object} Response "参数无效"
+// @Router /api/v1/security/login-attempts/export [get]
+func (c *ExportController) ExportLoginAttempts(ctx *gin.Context) {
+	c.export(ctx, Services.ExportDatasetLoginAttempts)
+}
+
+// export 解析参数并把导出文件写入响应
+func (c *ExportController) export(ctx *gin.Context, dataset string) {
+	format := strings.ToLower(ctx.DefaultQuery("format", Services.ExportFormatCSV))
+	if !Services.IsExportFormat(format) {
+		c.Error(ctx, http.StatusBadRequest, Services.ErrUnsupportedExportFormat.Error())
+		return
+	}
+	q, err := Services.ParseExportQuery(dataset, ctx.Request.URL.Query())
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	req := Services.DataExportRequest{
+		Dataset:  dataset,
+		Format:   format,
+		Query:    q,
+		Status:   ctx.Query("status"),
+		Severity: ctx.Query("severity"),
+		MaxRows:  c.exportService.MaxRows(),
+	}
+	if dataset == Services.ExportDatasetAlerts {
+		userID, _ := c.GetCurrentUser(ctx)
+		req.Visible = Services.DefaultMonitoringVisibility().AlertFilter(Services.DashboardViewer{UserID: userID, Role: c.GetCurrentUserRole(ctx)})
+	}
+	if since := ctx.Query("since"); since != "" {
+		if t, err := time.Parse(time.RFC3339, since); err == nil {
+			req.Since = t
+		} else if d, err := time.ParseDuration(since); err == nil && d > 0 {
+			req.Since = time.Now().Add(-d)
+		} else {
+			c.Error(ctx, http.StatusBadRequest, "since 参数无效，应为RFC3339时间或时长")
+			return
+		}
+	}
+
+	// 响应开始写入后不能再修改状态码和响应头，导出行数和是否截断通过 Trailer 返回
+	filename := Services.ExportFileName(dataset, format, time.Now())
+	ctx.Header("Content-Type", Services.ExportContentType(format))
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	ctx.Header("Trailer", "X-Export-Rows, X-Export-Truncated")
+	ctx.Status(http.StatusOK)
+
+	result, err := c.exportService.Export(ctx.Request.Context(), ctx.Writer, req)
+	if err != nil {
+		log.Printf("数据导出失败: dataset=%s, format=%s, rows=%d, error=%v", dataset, format, result.Rows, err)
+		if !ctx.Writer.Written() {
+			ctx.Writer.Header().Del("Content-Disposition")
+			ctx.Writer.Header().Del("Trailer")
+			ctx.Writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+			c.Error(ctx, http.StatusInternalServerError, "数据导出失败")
+		}
+		ctx.Abort()
+		return
+	}
+	ctx.Writer.Header().Set("X-Export-Rows", strconv.Itoa(result.Rows))
+	ctx.Writer.Header().Set("X-Export-Truncated", strconv.FormatBool(result.Truncated))
+}
+
+// GetExportSchedules 获取定时导出列表
+// @Summary 获取定时导出列表
+// @Tags 数据导出
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "定时导出列表"
+// @Router /api/v1/exports/schedules [get]
+func (c *ExportController) GetExportSchedules(ctx *gin.Context) {
+	schedules, err := c.exportService.ListSchedules()
+	if err != nil {
+		c.exportError(ctx, err)
+		return
+	}
+	c.Success(ctx, schedules, "定时导出列表获取成功")
+}
+
+// GetExportSchedule 获取定时导出
+// @Summary 获取定时导出
+// @Tags 数据导出
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "定时导出ID"
+// @Success 200 {object} Response "定时导出"
+// @Failure 404 {object} Response "定时导出不存在"
+// @Router /api/v1/exports/schedules/{id} [get]
+func (c *ExportController) GetExportSchedule(ctx *gin.Context) {
+	id, ok := c.pathID(ctx)
+	if !ok {
+		return
+	}
+	schedule, err := c.exportService.GetSchedule(id)
+	if err != nil {
+		c.exportError(ctx, err)
+		return
+	}
+	c.Success(ctx, schedule, "定时导出获取成功")
+}
+
+// CreateExportSchedule 创建定时导出
+// @Summary 创建定时导出
+// @Description query 为列表接口的查询字符串（如 filter[event_level]=high&sort=-created_at），since 为每次导出覆盖的时长
+// @Tags 数据导出
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param schedule body Services.ExportScheduleInput true "定时导出"
+// @Success 201 {object} Response "创建的定时导出"
+// @Failure 400 {object} Response "参数无效"
+// @Router /api/v1/exports/schedules [post]
+func (c *ExportController) CreateExportSchedule(ctx *gin.Context) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "需要登录")
+		return
+	}
+	var input Services.ExportScheduleInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	schedule, err := c.exportService.CreateSchedule(input, userID)
+	if err != nil {
+		c.exportError(ctx, err)
+		return
+	}
+	c.Created(ctx, schedule, "定时导出已创建")
+}
+
+// UpdateExportSchedule 更新定时导出
+// @Summary 更新定时导出
+// @Tags 数据导出
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "定时导出ID"
+// @Param schedule body Services.ExportScheduleInput true "定时导出"
+// @Success 200 {object} Response "更新后的定时导出"
+// @Failure 400 {object} Response "参数无效"
+// @Failure 404 {object} Response "定时导出不存在"
+// @Router /api/v1/exports/schedules/{id} [put]
+func (c *ExportController) UpdateExportSchedule(ctx *gin.Context) {
+	id, ok := c.pathID(ctx)
+	if !ok {
+		return
+	}
+	var input Services.ExportScheduleInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	schedule, err := c.exportService.UpdateSchedule(id, input)
+	if err != nil {
+		c.exportError(ctx, err)
+		return
+	}
+	c.Success(ctx, schedule, "定时导出已更新")
+}
+
+// DeleteExportSchedule 删除定时导出
+// @Summary 删除定时导出
+// @Tags 数据导出
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "定时导出ID"
+// @Success 200 {object} Response "删除成功"
+// @Failure 404 {object} Response "定时导出不存在"
+// @Router /api/v1/exports/schedules/{id} [delete]
+func (c *ExportController) DeleteExportSchedule(ctx *gin.Context) {
+	id, ok := c.pathID(ctx)
+	if !ok {
+		return
+	}
+	if err := c.exportService.DeleteSchedule(id); err != nil {
+		c.exportError(ctx, err)
+		return
+	}
+	c.Success(ctx, nil, "定时导出已删除")
+}
+
+// RunExportSchedule 立即运行定时导出
+// @Summary 立即运行定时导出
+// @Description 立即生成导出文件并发送邮件，不影响下次运行时间
+// @Tags 数据导出
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "定时导出ID"
+// @Success 200 {object} Response "运行结果"
+// @Failure 404 {object} Response "定时导出不存在"
+// @Router /api/v1/exports/schedules/{id}/run [post]
+func (c *ExportController) RunExportSchedule(ctx *gin.Context) {
+	id, ok := c.pathID(ctx)
+	if !ok {
+		return
+	}
+	schedule, err := c.exportService.RunSchedule(ctx.Request.Context(), id)
+	if err != nil {
+		c.exportError(ctx, err)
+		return
+	}
+	c.Success(ctx, schedule, "定时导出已运行")
+}
+
+// pathID 解析路径中的定时导出ID
+func (c *ExportController) pathID(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		c.Error(ctx, http.StatusBadRequest, "无效的定时导出ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// exportError 按错误类型返回响应
+func (c *ExportController) exportError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, Services.ErrExportScheduleNotFound):
+		c.Error(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, Services.ErrInvalidExportSchedule):
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+	default:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+}
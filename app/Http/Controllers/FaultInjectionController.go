@@ -0,0 +1,107 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FaultInjectionController 故障注入管理控制器
+type FaultInjectionController struct {
+	Controller
+	injector *Services.FaultInjector
+}
+
+// NewFaultInjectionController 创建故障注入管理控制器
+func NewFaultInjectionController(injector *Services.FaultInjector) *FaultInjectionController {
+	return &FaultInjectionController{injector: injector}
+}
+
+// FaultInjectionClearResponse 撤销全部故障注入的响应
+type FaultInjectionClearResponse struct {
+	Removed int `json:"removed"`
+}
+
+// GetFaults 获取生效中的故障注入
+// @Summary 获取生效中的故障注入
+// @Description 已过期的注入不会返回（仅管理员）
+// @Tags 故障注入
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "故障注入列表"
+// @Router /api/v1/admin/fault-injections [get]
+func (c *FaultInjectionController) GetFaults(ctx *gin.Context) {
+	c.Success(ctx, c.injector.List(), "故障注入获取成功")
+}
+
+// CreateFault 创建故障注入
+// @Summary 创建故障注入
+// @Description 按路由或依赖注入延迟、错误、数据库断连或Redis超时，到期自动失效（仅管理员）
+// @Tags 故障注入
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param fault body Services.FaultInjectionInput true "故障注入"
+// @Success 201 {object} Response "创建的故障注入"
+// @Failure 400 {object} Response "参数无效"
+// @Router /api/v1/admin/fault-injections [post]
+func (c *FaultInjectionController) CreateFault(ctx *gin.Context) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Error(ctx, http.StatusUnauthorized, "需要登录")
+		return
+	}
+	var input Services.FaultInjectionInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	fault, err := c.injector.Inject(input, userID)
+	if err != nil {
+		c.faultError(ctx, err)
+		return
+	}
+	c.Created(ctx, fault, "故障注入已创建")
+}
+
+// DeleteFault 撤销故障注入
+// @Summary 撤销故障注入
+// @Tags 故障注入
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "故障注入ID"
+// @Success 200 {object} Response "撤销成功"
+// @Failure 404 {object} Response "故障注入不存在或已过期"
+// @Router /api/v1/admin/fault-injections/{id} [delete]
+func (c *FaultInjectionController) DeleteFault(ctx *gin.Context) {
+	if err := c.injector.Remove(ctx.Param("id")); err != nil {
+		c.faultError(ctx, err)
+		return
+	}
+	c.Success(ctx, nil, "故障注入已撤销")
+}
+
+// ClearFaults 撤销全部故障注入
+// @Summary 撤销全部故障注入
+// @Tags 故障注入
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "撤销的数量"
+// @Router /api/v1/admin/fault-injections [delete]
+func (c *FaultInjectionController) ClearFaults(ctx *gin.Context) {
+	c.Success(ctx, FaultInjectionClearResponse{Removed: c.injector.Clear()}, "故障注入已全部撤销")
+}
+
+// faultError 按错误类型返回状态码
+func (c *FaultInjectionController) faultError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, Services.ErrFaultInjectionNotFound):
+		c.Error(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, Services.ErrInvalidFaultInjection):
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+	default:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"net/http"
 	"runtime"
+	"sort"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -26,6 +27,8 @@ type HealthController struct {
 	securityService *Services.SecurityService
 	startTime       time.Time
 	customChecks    map[string]func() error
+	readinessGates  map[string]func() error
+	startup         *Services.StartupOrchestrator
 }
 
 // NewHealthController 创建健康检查控制器
@@ -39,6 +42,8 @@ func NewHealthController() *HealthController {
 		storageManager: Storage.GetStorageManager(),
 		startTime:      time.Now(),
 		customChecks:   make(map[string]func() error),
+		readinessGates: make(map[string]func() error),
+		startup:        Services.DefaultStartupOrchestrator(),
 		// securityService 将在需要时通过依赖注入获取
 	}
 }
@@ -211,12 +216,8 @@ func (hc *HealthController) DetailedHealth(c *gin.Context) {
 // @Success 200 {object} map[string]interface{}
 // @Router /health/ready [get]
 func (hc *HealthController) Readiness(c *gin.Context) {
-	// 检查关键服务是否就绪
-	services := map[string]bool{
-		"database": hc.checkDatabase(),
-		"redis":    hc.checkRedis(),
-		"storage":  hc.checkStorage(),
-	}
+	// 检查关键服务和就绪门是否就绪
+	services := hc.readinessStatus()
 
 	ready := true
 	for service, status := range services {
@@ -250,6 +251,41 @@ func (hc *HealthController) Readiness(c *gin.Context) {
 	})
 }
 
+// DependencyReadiness 启动依赖就绪检查
+// @Summary 启动依赖就绪检查
+// @Description 返回启动时等待的各依赖的状态；依赖仍在等待时返回503，降级模式下返回200并列出暂停的功能
+// @Tags 健康检查
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /readyz [get]
+func (hc *HealthController) DependencyReadiness(c *gin.Context) {
+	if hc.startup == nil {
+		hc.Readiness(c)
+		return
+	}
+
+	state := hc.startup.State()
+	status := http.StatusOK
+	if state == Services.StartupStateStarting {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, gin.H{
+		"success": status == http.StatusOK,
+		"message": fmt.Sprintf("Application is %s", state),
+		"data": gin.H{
+			"status":            state,
+			"dependencies":      hc.startup.Statuses(),
+			"disabled_features": hc.startup.DisabledFeatures(),
+		},
+	})
+}
+
+// SetStartupOrchestrator 设置启动编排服务，/readyz 使用其中的依赖状态
+func (hc *HealthController) SetStartupOrchestrator(startup *Services.StartupOrchestrator) {
+	hc.startup = startup
+}
+
 // Liveness 存活检查
 // @Summary 存活检查
 // @Description 检查应用是否存活
@@ -653,19 +689,58 @@ func (hc *HealthController) GetUptimeString() string {
 
 // ReadinessCheck 就绪检查方法（用于测试）
 func (hc *HealthController) ReadinessCheck() bool {
-	// 检查关键服务是否就绪
+	return hc.ReadinessError() == nil
+}
+
+// ReadinessError 返回第一个未就绪的服务或就绪门，全部就绪时返回nil
+// 供Kubernetes readiness gate同步使用
+func (hc *HealthController) ReadinessError() error {
+	services := hc.readinessStatus()
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !services[name] {
+			return fmt.Errorf("Service %s is not ready", name)
+		}
+	}
+	return nil
+}
+
+// AddReadinessGate 添加就绪门，检查返回错误时就绪检查失败
+// 与自定义健康检查不同，就绪门会影响 /health/ready 的结果
+func (hc *HealthController) AddReadinessGate(name string, check func() error) {
+	hc.readinessGates[name] = check
+}
+
+// RemoveReadinessGate 移除就绪门
+func (hc *HealthController) RemoveReadinessGate(name string) {
+	delete(hc.readinessGates, name)
+}
+
+// readinessStatus 检查关键服务和就绪门，就绪门中的panic视为未就绪
+func (hc *HealthController) readinessStatus() map[string]bool {
 	services := map[string]bool{
 		"database": hc.checkDatabase(),
 		"redis":    hc.checkRedis(),
 		"storage":  hc.checkStorage(),
 	}
-
-	for _, status := range services {
-		if !status {
-			return false
-		}
+	for name, check := range hc.readinessGates {
+		ready := false
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					ready = false
+				}
+			}()
+			ready = check() == nil
+		}()
+		services[name] = ready
 	}
-	return true
+	return services
 }
 
 // LivenessCheck 存活检查方法（用于测试）
@@ -0,0 +1,184 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Utils"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImpersonationController 管理员模拟登录控制器
+type ImpersonationController struct {
+	Controller
+	impersonationService *Services.ImpersonationService
+}
+
+// NewImpersonationController 创建模拟登录控制器
+func NewImpersonationController(service *Services.ImpersonationService) *ImpersonationController {
+	return &ImpersonationController{impersonationService: service}
+}
+
+// StartImpersonationRequest 发起模拟登录请求
+type StartImpersonationRequest struct {
+	UserID          uint   `json:"user_id" binding:"required"`        // 被模拟的用户ID
+	Reason          string `json:"reason" binding:"required,max=500"` // 模拟原因，写入审计日志并通知用户
+	DurationMinutes int    `json:"duration_minutes" binding:"min=0"`  // 有效期（分钟），为0时使用默认有效期
+	ReadOnly        bool   `json:"read_only"`                         // 只读模拟，只允许查询
+}
+
+// StartImpersonation 发起模拟登录
+// @Summary 发起模拟登录
+// @Description 以目标用户身份签发限时模拟令牌，模拟期间每个请求记录审计日志，修改密码、创建API密钥等敏感操作被拒绝，被模拟的用户会收到站内通知（仅管理员）
+// @Tags 模拟登录
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body StartImpersonationRequest true "模拟登录参数"
+// @Success 201 {object} Response "模拟令牌和模拟会话"
+// @Failure 403 {object} Response "不能模拟该用户或未启用模拟登录"
+// @Failure 404 {object} Response "用户不存在"
+// @Router /api/v1/admin/impersonations [post]
+func (c *ImpersonationController) StartImpersonation(ctx *gin.Context) {
+	adminID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "用户未登录")
+		return
+	}
+	var req StartImpersonationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "请求参数无效: "+err.Error())
+		return
+	}
+
+	result, err := c.impersonationService.Start(adminID, Services.ImpersonationRequest{
+		TargetUserID:  req.UserID,
+		Reason:        req.Reason,
+		Duration:      time.Duration(req.DurationMinutes) * time.Minute,
+		ReadOnly:      req.ReadOnly,
+		IPAddress:     ctx.ClientIP(),
+		RequestID:     ctx.GetString(Utils.RequestIDKey),
+		CorrelationID: ctx.GetString(Utils.CorrelationIDKey),
+	})
+	if err != nil {
+		c.impersonationError(ctx, err)
+		return
+	}
+	c.Created(ctx, result, "模拟令牌已签发")
+}
+
+// ImpersonationListQuery 模拟会话列表的额外查询参数
+type ImpersonationListQuery struct {
+	Active bool `form:"active"` // 只返回未结束且未过期的会话
+}
+
+// ImpersonationQuerySpec 模拟会话列表可筛选、排序和返回的字段
+var ImpersonationQuerySpec = Utils.QuerySpec{
+	Fields: map[string]Utils.QueryField{
+		"id":              {Column: "id", Type: Utils.QueryInt, Filter: true, Sort: true},
+		"session_id":      {Column: "session_id", Type: Utils.QueryString, Filter: true},
+		"impersonator_id": {Column: "impersonator_id", Type: Utils.QueryInt, Filter: true},
+		"impersonator":    {Column: "impersonator_name", Type: Utils.QueryString, Filter: true},
+		"target_user_id":  {Column: "target_user_id", Type: Utils.QueryInt, Filter: true},
+		"target_username": {Column: "target_username", Type: Utils.QueryString, Filter: true},
+		"reason":          {Column: "reason", Type: Utils.QueryString, Filter: true},
+		"read_only":       {Column: "read_only", Type: Utils.QueryBool, Filter: true},
+		"ip_address":      {Column: "ip_address", Type: Utils.QueryString, Filter: true},
+		"request_count":   {Column: "request_count", Type: Utils.QueryInt, Filter: true, Sort: true},
+		"last_request_at": {Column: "last_request_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+		"expires_at":      {Column: "expires_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+		"ended_at":        {Column: "ended_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+		"ended_by":        {Column: "ended_by", Type: Utils.QueryInt, Filter: true},
+		"created_at":      {Column: "created_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+	},
+	DefaultSort: "-id",
+}
+
+// GetImpersonations 获取模拟会话列表
+// @Summary 获取模拟会话列表
+// @Description 分页查询模拟会话，支持 filter[field][op]=value 筛选和排序，active=true 时只返回未结束且未过期的会话（仅管理员）
+// @Tags 模拟登录
+// @Produce json
+// @Security ApiKeyAuth
+// @Param active query bool false "只返回进行中的会话"
+// @Param filter[target_user_id][eq] query int false "按被模拟的用户筛选，字段和操作符见接口文档"
+// @Param sort query string false "排序字段，逗号分隔，前缀-表示降序" default(-id)
+// @Param page query int false "页码" default(1)
+// @Param limit query int false "每页数量" default(20)
+// @Success 200 {object} Response "模拟会话列表"
+// @Failure 400 {object} Response "查询参数无效"
+// @Router /api/v1/admin/impersonations [get]
+func (c *ImpersonationController) GetImpersonations(ctx *gin.Context) {
+	req, err := c.ParsePageRequest(ctx)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	q, err := Utils.ParseListQuery(ctx.Request.URL.Query(), ImpersonationQuerySpec)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	activeOnly, _ := strconv.ParseBool(ctx.Query("active"))
+	sessions, meta, err := c.impersonationService.List(q, req, activeOnly)
+	if err != nil {
+		c.impersonationError(ctx, err)
+		return
+	}
+	data, err := q.Project(sessions)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取模拟会话失败: "+err.Error())
+		return
+	}
+	c.ListSuccess(ctx, data, meta, "模拟会话获取成功")
+}
+
+// EndImpersonation 结束模拟会话
+// @Summary 结束模拟会话
+// @Description 会话的模拟令牌立即失效，被模拟的用户会收到站内通知（仅管理员）
+// @Tags 模拟登录
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "模拟会话ID"
+// @Success 200 {object} Response "已结束的模拟会话"
+// @Failure 404 {object} Response "模拟会话不存在"
+// @Failure 409 {object} Response "模拟会话已结束"
+// @Router /api/v1/admin/impersonations/{id} [delete]
+func (c *ImpersonationController) EndImpersonation(ctx *gin.Context) {
+	adminID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "用户未登录")
+		return
+	}
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.Error(ctx, http.StatusBadRequest, "模拟会话ID无效")
+		return
+	}
+	session, err := c.impersonationService.End(uint(id), adminID, ctx.GetString("username"))
+	if err != nil {
+		c.impersonationError(ctx, err)
+		return
+	}
+	c.Success(ctx, session, "模拟会话已结束")
+}
+
+// impersonationError 模拟登录错误响应
+func (c *ImpersonationController) impersonationError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, Services.ErrImpersonationUserNotFound), errors.Is(err, Services.ErrImpersonationNotFound):
+		c.Error(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, Services.ErrImpersonationForbidden), errors.Is(err, Services.ErrImpersonationDisabled):
+		c.Error(ctx, http.StatusForbidden, err.Error())
+	case errors.Is(err, Services.ErrImpersonationDuration), errors.Is(err, Utils.ErrInvalidCursor), errors.Is(err, Utils.ErrInvalidQuery):
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+	case errors.Is(err, Services.ErrImpersonationEnded):
+		c.Error(ctx, http.StatusConflict, err.Error())
+	default:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,153 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Utils"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InspectorController 请求检查器控制器
+type InspectorController struct {
+	Controller
+	inspector *Services.RequestInspector
+}
+
+// NewInspectorController 创建请求检查器控制器
+func NewInspectorController(inspector *Services.RequestInspector) *InspectorController {
+	return &InspectorController{inspector: inspector}
+}
+
+// InspectorClearResponse 清空检查器记录的响应
+type InspectorClearResponse struct {
+	Removed int64 `json:"removed"`
+}
+
+// InspectorEntryQuerySpec 检查器记录列表可筛选、排序和返回的字段
+var InspectorEntryQuerySpec = Utils.QuerySpec{
+	Fields: map[string]Utils.QueryField{
+		"id":                 {Column: "id", Type: Utils.QueryInt, Filter: true, Sort: true},
+		"request_id":         {Column: "request_id", Type: Utils.QueryString, Filter: true},
+		"method":             {Column: "method", Type: Utils.QueryString, Filter: true},
+		"path":               {Column: "path", Type: Utils.QueryString, Filter: true},
+		"route":              {Column: "route", Type: Utils.QueryString, Filter: true},
+		"status_code":        {Column: "status_code", Type: Utils.QueryInt, Filter: true, Sort: true},
+		"duration_ms":        {Column: "duration_ms", Type: Utils.QueryFloat, Filter: true, Sort: true},
+		"user_id":            {Column: "user_id", Type: Utils.QueryInt, Filter: true},
+		"client_ip":          {Column: "client_ip", Type: Utils.QueryString, Filter: true},
+		"exception":          {Column: "exception", Type: Utils.QueryString},
+		"has_exception":      {Column: "has_exception", Type: Utils.QueryBool, Filter: true},
+		"query_count":        {Column: "query_count", Type: Utils.QueryInt, Filter: true, Sort: true},
+		"query_ms":           {Column: "query_ms", Type: Utils.QueryFloat, Filter: true, Sort: true},
+		"cache_count":        {Column: "cache_count", Type: Utils.QueryInt, Filter: true},
+		"http_count":         {Column: "http_count", Type: Utils.QueryInt, Filter: true},
+		"notification_count": {Column: "notification_count", Type: Utils.QueryInt, Filter: true},
+		"created_at":         {Column: "created_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+	},
+	DefaultSort: "-created_at",
+}
+
+// ListRequests 获取检查器记录列表
+// @Summary 获取检查器记录列表
+// @Description 分页查询请求检查器记录，支持 filter[status_code][gte]=500、filter[has_exception]=true、filter[path][like]=users 等筛选（仅管理员）
+// @Tags 请求检查器
+// @Produce json
+// @Security ApiKeyAuth
+// @Param filter[status_code][gte] query int false "按状态码筛选"
+// @Param filter[user_id] query int false "按用户筛选"
+// @Param sort query string false "排序字段，逗号分隔，前缀-表示降序" default(-created_at)
+// @Param fields query string false "返回字段，逗号分隔"
+// @Param page query int false "页码" default(1)
+// @Param limit query int false "每页数量" default(20)
+// @Param cursor query string false "上一页返回的 meta.cursor，传入时使用游标分页"
+// @Success 200 {object} Response "记录列表"
+// @Failure 400 {object} Response "查询参数无效"
+// @Router /api/v1/admin/inspector/requests [get]
+func (c *InspectorController) ListRequests(ctx *gin.Context) {
+	req, err := c.ParsePageRequest(ctx)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	q, err := Utils.ParseListQuery(ctx.Request.URL.Query(), InspectorEntryQuerySpec)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entries, meta, err := c.inspector.List(q, req)
+	if errors.Is(err, Utils.ErrInvalidCursor) || errors.Is(err, Utils.ErrInvalidQuery) {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取检查器记录失败: "+err.Error())
+		return
+	}
+
+	data, err := q.Project(entries)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取检查器记录失败: "+err.Error())
+		return
+	}
+	c.ListSuccess(ctx, data, meta, "检查器记录获取成功")
+}
+
+// GetRequest 获取检查器记录详情
+// @Summary 获取检查器记录详情
+// @Description 返回请求头、请求体、响应头、响应体、异常，以及请求执行的SQL、缓存操作、出站HTTP调用和通知（仅管理员）
+// @Tags 请求检查器
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "记录ID"
+// @Success 200 {object} Response "记录详情"
+// @Failure 404 {object} Response "记录不存在"
+// @Router /api/v1/admin/inspector/requests/{id} [get]
+func (c *InspectorController) GetRequest(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, "无效的记录ID")
+		return
+	}
+	detail, err := c.inspector.Get(uint(id))
+	if errors.Is(err, Services.ErrInspectorEntryNotFound) {
+		c.Error(ctx, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取检查器记录失败: "+err.Error())
+		return
+	}
+	c.Success(ctx, detail, "检查器记录获取成功")
+}
+
+// GetStats 获取请求检查器统计
+// @Summary 获取请求检查器统计
+// @Description 返回当前实例写入、丢弃和写入失败的记录数（仅管理员）
+// @Tags 请求检查器
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "统计"
+// @Router /api/v1/admin/inspector/stats [get]
+func (c *InspectorController) GetStats(ctx *gin.Context) {
+	c.Success(ctx, c.inspector.Stats(), "检查器统计获取成功")
+}
+
+// ClearRequests 清空检查器记录
+// @Summary 清空检查器记录
+// @Tags 请求检查器
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "清除的数量"
+// @Router /api/v1/admin/inspector/requests [delete]
+func (c *InspectorController) ClearRequests(ctx *gin.Context) {
+	removed, err := c.inspector.Clear()
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "清空检查器记录失败: "+err.Error())
+		return
+	}
+	c.Success(ctx, InspectorClearResponse{Removed: removed}, "检查器记录已清空")
+}
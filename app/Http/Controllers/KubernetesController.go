@@ -0,0 +1,115 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// externalMetricsGroupVersion 外部指标API的组和版本
+const externalMetricsGroupVersion = "external.metrics.k8s.io/v1beta1"
+
+// KubernetesController Kubernetes集成控制器
+// 功能说明：
+// 1. 外部指标接口按Kubernetes API格式响应（不使用统一响应包装），由API聚合层转发HPA的查询
+// 2. 部署信息接口返回识别到的Pod信息、部署标签和readiness gate同步状态
+type KubernetesController struct {
+	Controller
+	integration *Services.KubernetesIntegration
+}
+
+// NewKubernetesController 创建Kubernetes集成控制器
+func NewKubernetesController(integration *Services.KubernetesIntegration) *KubernetesController {
+	return &KubernetesController{integration: integration}
+}
+
+// GetKubernetesStatus 获取Kubernetes部署信息
+// @Summary 获取Kubernetes部署信息
+// @Description 返回识别到的Pod、命名空间、节点，附加到指标和日志的部署标签，readiness gate同步状态和提供给HPA的外部指标（仅管理员）
+// @Tags 监控告警
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "部署信息"
+// @Router /api/v1/monitoring/kubernetes [get]
+func (c *KubernetesController) GetKubernetesStatus(ctx *gin.Context) {
+	c.Success(ctx, gin.H{
+		"metadata":         c.integration.Metadata(),
+		"labels":           c.integration.Labels(),
+		"readiness_gate":   c.integration.ReadinessGateStatus(),
+		"external_metrics": c.integration.ExternalMetricNames(),
+	}, "获取Kubernetes部署信息成功")
+}
+
+// GetExternalMetricResources 外部指标API资源发现
+// @Summary 外部指标API资源发现
+// @Description 返回 external.metrics.k8s.io/v1beta1 下可查询的指标列表
+// @Tags Kubernetes
+// @Produce json
+// @Success 200 {object} map[string]interface{} "APIResourceList"
+// @Router /apis/external.metrics.k8s.io/v1beta1 [get]
+func (c *KubernetesController) GetExternalMetricResources(ctx *gin.Context) {
+	resources := make([]gin.H, 0)
+	for _, name := range c.integration.ExternalMetricNames() {
+		resources = append(resources, gin.H{
+			"name":         name,
+			"singularName": "",
+			"namespaced":   true,
+			"kind":         "ExternalMetricValueList",
+			"verbs":        []string{"get"},
+		})
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"kind":         "APIResourceList",
+		"apiVersion":   "v1",
+		"groupVersion": externalMetricsGroupVersion,
+		"resources":    resources,
+	})
+}
+
+// GetExternalMetric 查询外部指标
+// @Summary 查询外部指标
+// @Description 按 external.metrics.k8s.io/v1beta1 格式返回监控核心中的指标值，供HPA按业务负载扩缩容
+// @Tags Kubernetes
+// @Produce json
+// @Param namespace path string true "命名空间"
+// @Param metric path string true "指标名"
+// @Param labelSelector query string false "标签选择器，如 queue=emails"
+// @Success 200 {object} map[string]interface{} "ExternalMetricValueList"
+// @Failure 400 {object} map[string]interface{} "标签选择器无效"
+// @Failure 404 {object} map[string]interface{} "指标不存在"
+// @Router /apis/external.metrics.k8s.io/v1beta1/namespaces/{namespace}/{metric} [get]
+func (c *KubernetesController) GetExternalMetric(ctx *gin.Context) {
+	items, err := c.integration.ExternalMetrics(ctx.Param("namespace"), ctx.Param("metric"), ctx.Query("labelSelector"))
+	if err != nil {
+		switch {
+		case errors.Is(err, Services.ErrExternalMetricNotFound):
+			c.kubernetesStatus(ctx, http.StatusNotFound, "NotFound", err.Error())
+		case errors.Is(err, Services.ErrInvalidLabelSelector):
+			c.kubernetesStatus(ctx, http.StatusBadRequest, "BadRequest", err.Error())
+		default:
+			c.kubernetesStatus(ctx, http.StatusInternalServerError, "InternalError", err.Error())
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"kind":       "ExternalMetricValueList",
+		"apiVersion": externalMetricsGroupVersion,
+		"metadata":   gin.H{},
+		"items":      items,
+	})
+}
+
+// kubernetesStatus 按Kubernetes Status对象返回错误
+func (c *KubernetesController) kubernetesStatus(ctx *gin.Context, code int, reason, message string) {
+	ctx.JSON(code, gin.H{
+		"kind":       "Status",
+		"apiVersion": "v1",
+		"metadata":   gin.H{},
+		"status":     "Failure",
+		"message":    message,
+		"reason":     reason,
+		"code":       code,
+	})
+}
@@ -98,6 +98,7 @@ func (c *LogController) GetLogStats(ctx *gin.Context) {
 	c.Success(ctx, gin.H{
 		"overview":     filteredStats,
 		"logger_stats": loggerStats,
+		"shipping":     c.logManager.GetShippingStats(),
 		"timestamp":    time.Now(),
 	}, "获取日志统计信息成功")
 }
@@ -0,0 +1,446 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/I18n"
+	"cloud-platform-api/app/Services"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// LogQueryController 日志查询控制器
+//
+// 重要功能说明：
+// 1. 日志搜索：按日志记录器、级别、时间范围、链路追踪ID和关键词搜索日志文件
+// 2. 日志文件列表：查看各日志记录器的日志文件
+// 3. 实时跟踪：通过SSE或WebSocket推送错误日志和安全日志的新增内容
+// 4. 运行时调整：查看和修改各日志记录器的级别和采样率，无需重启
+// 5. 文件维护：手动轮转日志文件、把队列中的日志刷到磁盘
+//
+// 安全特性：
+// - 所有接口都需要管理员权限（在路由中配置）
+// - 实时跟踪只开放错误日志和安全日志
+type LogQueryController struct {
+	Controller
+	logManager      *Services.LogManagerService
+	logQueryService *Services.LogQueryService
+	upgrader        websocket.Upgrader
+}
+
+// NewLogQueryController 创建日志查询控制器
+func NewLogQueryController(logManager *Services.LogManagerService) *LogQueryController {
+	return &LogQueryController{
+		logManager:      logManager,
+		logQueryService: Services.NewLogQueryService(logManager.GetConfig()),
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 4096,
+		},
+	}
+}
+
+// Search 搜索日志
+// @Summary 搜索日志
+// @Description 按日志记录器、级别、时间范围、链路追踪ID和关键词搜索日志，结果按时间倒序分页（仅管理员）
+// @Tags 日志管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param logger query string false "日志记录器，逗号分隔" Enums(request,sql,error,audit,security,business,access)
+// @Param level query string false "日志级别，逗号分隔"
+// @Param min_level query string false "最低日志级别"
+// @Param from query string false "开始时间（RFC3339或YYYY-MM-DD）"
+// @Param to query string false "结束时间（RFC3339或YYYY-MM-DD）"
+// @Param trace_id query string false "链路追踪ID"
+// @Param q query string false "关键词"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(50)
+// @Success 200 {object} Response "查询结果"
+// @Failure 400 {object} Response "请求参数错误"
+// @Router /api/v1/logs/search [get]
+func (c *LogQueryController) Search(ctx *gin.Context) {
+	query, ok := c.parseQuery(ctx)
+	if !ok {
+		return
+	}
+	query.Page, _ = strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	query.PageSize, _ = strconv.Atoi(ctx.DefaultQuery("page_size", "0"))
+
+	var err error
+	if query.From, err = parseSearchTime(ctx.Query("from"), false); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "logs.invalid_time")
+		return
+	}
+	if query.To, err = parseSearchTime(ctx.Query("to"), true); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "logs.invalid_time")
+		return
+	}
+
+	result, err := c.logQueryService.Search(ctx.Request.Context(), query)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, c.Trans(ctx, "logs.search_failed", I18n.Params{"error": err.Error()}))
+		return
+	}
+
+	c.Success(ctx, result, "logs.search_success")
+}
+
+// GetFiles 获取日志文件列表
+// @Summary 获取日志文件列表
+// @Description 获取日志文件的名称、日期和大小（仅管理员）
+// @Tags 日志管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param logger query string false "日志记录器"
+// @Success 200 {object} Response "文件列表"
+// @Router /api/v1/logs/files [get]
+func (c *LogQueryController) GetFiles(ctx *gin.Context) {
+	logger := ctx.Query("logger")
+	if logger != "" && !c.logQueryService.HasLogger(logger) {
+		c.Error(ctx, http.StatusBadRequest, c.Trans(ctx, "logs.unknown_logger", I18n.Params{"logger": logger}))
+		return
+	}
+
+	files, err := c.logQueryService.Files(logger)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, c.Trans(ctx, "logs.search_failed", I18n.Params{"error": err.Error()}))
+		return
+	}
+
+	c.Success(ctx, gin.H{
+		"files":    files,
+		"tailable": Services.TailableLoggers,
+	}, "logs.files_success")
+}
+
+// GetStats 获取日志统计
+// @Summary 获取日志统计
+// @Description 获取日志数量、级别分布、采样过滤数、队列丢弃数、投递统计和按模型的SQL语句统计（仅管理员）
+// @Tags 日志管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "日志统计"
+// @Router /api/v1/logs/stats [get]
+func (c *LogQueryController) GetStats(ctx *gin.Context) {
+	c.Success(ctx, gin.H{
+		"stats":    c.logManager.GetStats(),
+		"shipping": c.logManager.GetShippingStats(),
+		"sql":      c.logManager.SQLStats(),
+	}, "logs.stats_success")
+}
+
+// GetLevels 获取日志级别
+// @Summary 获取日志级别
+// @Description 获取各日志记录器当前的级别和采样率（仅管理员）
+// @Tags 日志管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "日志级别列表"
+// @Router /api/v1/logs/levels [get]
+func (c *LogQueryController) GetLevels(ctx *gin.Context) {
+	c.Success(ctx, c.logManager.GetLoggerLevels(), "logs.levels_success")
+}
+
+// UpdateLevelRequest 调整日志级别请求
+type UpdateLevelRequest struct {
+	Level      string `json:"level"`       // 日志级别，为空表示不修改
+	SampleRate int    `json:"sample_rate"` // 采样率（每N条保留1条），0表示不修改
+}
+
+// UpdateLevel 调整日志级别
+// @Summary 调整日志级别
+// @Description 运行时调整日志记录器的级别和采样率，立即生效，重启后恢复为配置值（仅管理员）
+// @Tags 日志管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param logger path string true "日志记录器"
+// @Param request body UpdateLevelRequest true "级别和采样率"
+// @Success 200 {object} Response "调整成功"
+// @Failure 400 {object} Response "请求参数错误"
+// @Router /api/v1/logs/levels/{logger} [put]
+func (c *LogQueryController) UpdateLevel(ctx *gin.Context) {
+	logger := ctx.Param("logger")
+	if !c.logQueryService.HasLogger(logger) {
+		c.Error(ctx, http.StatusNotFound, c.Trans(ctx, "logs.unknown_logger", I18n.Params{"logger": logger}))
+		return
+	}
+
+	var req UpdateLevelRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "common.invalid_request")
+		return
+	}
+	if req.Level == "" && req.SampleRate == 0 {
+		c.Error(ctx, http.StatusBadRequest, "logs.level_required")
+		return
+	}
+
+	if req.Level != "" {
+		level := Config.LogLevel(strings.ToLower(req.Level))
+		if level.Severity() < 0 {
+			c.Error(ctx, http.StatusBadRequest, c.Trans(ctx, "logs.invalid_level", I18n.Params{"level": req.Level}))
+			return
+		}
+		if err := c.logManager.SetLoggerLevel(logger, level); err != nil {
+			c.Error(ctx, http.StatusBadRequest, c.Trans(ctx, "logs.level_failed", I18n.Params{"error": err.Error()}))
+			return
+		}
+	}
+	if req.SampleRate != 0 {
+		if err := c.logManager.SetSampleRate(logger, req.SampleRate); err != nil {
+			c.Error(ctx, http.StatusBadRequest, c.Trans(ctx, "logs.level_failed", I18n.Params{"error": err.Error()}))
+			return
+		}
+	}
+
+	c.logManager.LogAudit(ctx.Request.Context(), "update_log_level", "logger", logger, map[string]interface{}{
+		"level":       req.Level,
+		"sample_rate": req.SampleRate,
+		"operator":    ctx.GetString("username"),
+	})
+
+	for _, info := range c.logManager.GetLoggerLevels() {
+		if info.Logger == logger {
+			c.Success(ctx, info, "logs.level_updated")
+			return
+		}
+	}
+	c.Success(ctx, nil, "logs.level_updated")
+}
+
+// RotateLogsRequest 手动轮转日志请求
+type RotateLogsRequest struct {
+	Loggers []string `json:"loggers"` // 日志记录器，为空表示全部
+}
+
+// Rotate 手动轮转日志文件
+// @Summary 手动轮转日志文件
+// @Description 写完队列中的日志后轮转当前日志文件，立即压缩、归档轮转后的文件并按保留策略清理（仅管理员）
+// @Tags 日志管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body RotateLogsRequest false "日志记录器"
+// @Success 200 {object} Response "各日志记录器的轮转结果"
+// @Failure 400 {object} Response "请求参数错误"
+// @Router /api/v1/logs/rotate [post]
+func (c *LogQueryController) Rotate(ctx *gin.Context) {
+	var req RotateLogsRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			c.Error(ctx, http.StatusBadRequest, "common.invalid_request")
+			return
+		}
+	}
+	for _, logger := range req.Loggers {
+		if !c.logQueryService.HasLogger(logger) {
+			c.Error(ctx, http.StatusBadRequest, c.Trans(ctx, "logs.unknown_logger", I18n.Params{"logger": logger}))
+			return
+		}
+	}
+
+	rotateCtx, cancel := context.WithTimeout(ctx.Request.Context(), time.Minute)
+	defer cancel()
+	results, err := c.logManager.Rotate(rotateCtx, req.Loggers)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, c.Trans(ctx, "logs.rotate_failed", I18n.Params{"error": err.Error()}))
+		return
+	}
+
+	c.logManager.LogAudit(ctx.Request.Context(), "rotate_logs", "logger", strings.Join(req.Loggers, ","), map[string]interface{}{
+		"operator": ctx.GetString("username"),
+	})
+	c.Success(ctx, results, "logs.rotate_success")
+}
+
+// Flush 刷新日志
+// @Summary 刷新日志
+// @Description 等待队列中的日志全部写入并刷到磁盘（仅管理员）
+// @Tags 日志管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "刷新成功"
+// @Failure 500 {object} Response "等待写入超时"
+// @Router /api/v1/logs/flush [post]
+func (c *LogQueryController) Flush(ctx *gin.Context) {
+	flushCtx, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+	if err := c.logManager.Flush(flushCtx); err != nil {
+		c.Error(ctx, http.StatusInternalServerError, c.Trans(ctx, "logs.flush_failed", I18n.Params{"error": err.Error()}))
+		return
+	}
+	stats := c.logManager.GetStats()
+	c.Success(ctx, gin.H{"queue_depth": stats.QueueDepth}, "logs.flush_success")
+}
+
+// Tail 实时跟踪日志
+// @Summary 实时跟踪日志
+// @Description 推送错误日志或安全日志的新增内容（tail -f）。默认使用SSE，请求头包含WebSocket升级时使用WebSocket（仅管理员）
+// @Tags 日志管理
+// @Produce text/event-stream
+// @Security ApiKeyAuth
+// @Param logger path string true "日志记录器" Enums(error,security)
+// @Param level query string false "日志级别，逗号分隔"
+// @Param min_level query string false "最低日志级别"
+// @Param trace_id query string false "链路追踪ID"
+// @Param q query string false "关键词"
+// @Success 200 {string} string "日志事件流"
+// @Failure 403 {object} Response "该日志不支持实时跟踪"
+// @Router /api/v1/logs/tail/{logger} [get]
+func (c *LogQueryController) Tail(ctx *gin.Context) {
+	logger := ctx.Param("logger")
+	if !Services.IsTailable(logger) {
+		c.Error(ctx, http.StatusForbidden, c.Trans(ctx, "logs.tail_forbidden", I18n.Params{"loggers": strings.Join(Services.TailableLoggers, ", ")}))
+		return
+	}
+	if !c.logQueryService.HasLogger(logger) {
+		c.Error(ctx, http.StatusBadRequest, c.Trans(ctx, "logs.unknown_logger", I18n.Params{"logger": logger}))
+		return
+	}
+
+	query, ok := c.parseQuery(ctx)
+	if !ok {
+		return
+	}
+
+	if websocket.IsWebSocketUpgrade(ctx.Request) {
+		c.tailWebSocket(ctx, logger, query)
+		return
+	}
+	c.tailSSE(ctx, logger, query)
+}
+
+// tailSSE 通过SSE推送日志，每15秒发送一次心跳
+func (c *LogQueryController) tailSSE(ctx *gin.Context, logger string, query Services.LogQuery) {
+	entries, err := c.logQueryService.Tail(ctx.Request.Context(), logger, query)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, c.Trans(ctx, "logs.tail_failed", I18n.Params{"error": err.Error()}))
+		return
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+
+	ctx.SSEvent("ready", gin.H{"logger": logger})
+	ctx.Writer.Flush()
+
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case entry, ok := <-entries:
+			if !ok {
+				return false
+			}
+			ctx.SSEvent("log", entry)
+			return true
+		case <-heartbeat.C:
+			ctx.SSEvent("ping", time.Now().Unix())
+			return true
+		}
+	})
+}
+
+// tailWebSocket 通过WebSocket推送日志，客户端关闭连接时停止
+func (c *LogQueryController) tailWebSocket(ctx *gin.Context, logger string, query Services.LogQuery) {
+	conn, err := c.upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		// Upgrade 失败时已经写入了错误响应
+		return
+	}
+	defer conn.Close()
+
+	tailCtx, cancel := context.WithCancel(ctx.Request.Context())
+	defer cancel()
+
+	entries, err := c.logQueryService.Tail(tailCtx, logger, query)
+	if err != nil {
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, err.Error()))
+		return
+	}
+
+	// 读取协程只用于感知客户端关闭
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case entry, ok := <-entries:
+			if !ok {
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := conn.WriteJSON(entry); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// parseQuery 解析日志记录器、级别、链路追踪ID和关键词参数
+func (c *LogQueryController) parseQuery(ctx *gin.Context) (Services.LogQuery, bool) {
+	query := Services.LogQuery{
+		TraceID: strings.TrimSpace(ctx.Query("trace_id")),
+		Text:    strings.TrimSpace(ctx.Query("q")),
+	}
+
+	for _, logger := range splitQueryList(ctx.Query("logger")) {
+		if !c.logQueryService.HasLogger(logger) {
+			c.Error(ctx, http.StatusBadRequest, c.Trans(ctx, "logs.unknown_logger", I18n.Params{"logger": logger}))
+			return query, false
+		}
+		query.Loggers = append(query.Loggers, logger)
+	}
+
+	for _, level := range splitQueryList(ctx.Query("level")) {
+		logLevel := Config.LogLevel(strings.ToLower(level))
+		if logLevel.Severity() < 0 {
+			c.Error(ctx, http.StatusBadRequest, c.Trans(ctx, "logs.invalid_level", I18n.Params{"level": level}))
+			return query, false
+		}
+		query.Levels = append(query.Levels, logLevel)
+	}
+
+	if minLevel := ctx.Query("min_level"); minLevel != "" {
+		query.MinLevel = Config.LogLevel(strings.ToLower(minLevel))
+		if query.MinLevel.Severity() < 0 {
+			c.Error(ctx, http.StatusBadRequest, c.Trans(ctx, "logs.invalid_level", I18n.Params{"level": minLevel}))
+			return query, false
+		}
+	}
+
+	return query, true
+}
+
+// splitQueryList 拆分逗号分隔的参数
+func splitQueryList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
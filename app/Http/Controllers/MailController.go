@@ -0,0 +1,253 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// MailController 邮件投递记录和屏蔽列表控制器
+type MailController struct {
+	Controller
+	mailService   *Services.MailService
+	webhookSecret string
+}
+
+// NewMailController 创建邮件控制器
+//
+// webhookSecret 为空时退信回调接口不可用，避免任何人都能把地址加入屏蔽列表。
+func NewMailController(mailService *Services.MailService, webhookSecret string) *MailController {
+	return &MailController{
+		mailService:   mailService,
+		webhookSecret: webhookSecret,
+	}
+}
+
+// GetMessages 获取邮件投递记录
+// @Summary 获取邮件投递记录
+// @Description 按投递状态和收件人筛选邮件投递记录（仅管理员）
+// @Tags 邮件
+// @Produce json
+// @Security ApiKeyAuth
+// @Param status query string false "投递状态(queued/sending/sent/retrying/failed/suppressed/bounced)"
+// @Param recipient query string false "收件人"
+// @Param page query int false "页码"
+// @Param limit query int false "每页数量"
+// @Success 200 {object} Response "投递记录列表"
+// @Router /api/v1/mail/messages [get]
+func (c *MailController) GetMessages(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	messages, total, err := c.mailService.ListMessages(Services.MailMessageFilter{
+		Status:    ctx.Query("status"),
+		Recipient: ctx.Query("recipient"),
+		Page:      page,
+		Limit:     limit,
+	})
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取邮件投递记录失败: "+err.Error())
+		return
+	}
+
+	c.Success(ctx, gin.H{
+		"messages":    messages,
+		"total":       total,
+		"page":        page,
+		"limit":       limit,
+		"total_pages": (int(total) + limit - 1) / limit,
+	}, "邮件投递记录获取成功")
+}
+
+// GetMessage 获取邮件投递详情
+// @Summary 获取邮件投递详情
+// @Tags 邮件
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "投递记录ID"
+// @Success 200 {object} Response "投递记录"
+// @Failure 404 {object} Response "投递记录不存在"
+// @Router /api/v1/mail/messages/{id} [get]
+func (c *MailController) GetMessage(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, "投递记录ID无效")
+		return
+	}
+
+	message, err := c.mailService.GetMessage(uint(id))
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.Error(ctx, http.StatusNotFound, "投递记录不存在")
+	case err != nil:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	default:
+		c.Success(ctx, message, "邮件投递详情获取成功")
+	}
+}
+
+// RetryMessage 手动重试发送失败的邮件
+// @Summary 重试发送邮件
+// @Description 将发送失败或等待重试的邮件重新放入发送队列，重置重试次数（仅管理员）
+// @Tags 邮件
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "投递记录ID"
+// @Success 200 {object} Response "投递记录"
+// @Failure 404 {object} Response "投递记录不存在"
+// @Failure 409 {object} Response "当前状态不可重试"
+// @Router /api/v1/mail/messages/{id}/retry [post]
+func (c *MailController) RetryMessage(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, "投递记录ID无效")
+		return
+	}
+
+	message, err := c.mailService.Retry(uint(id))
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.Error(ctx, http.StatusNotFound, "投递记录不存在")
+	case errors.Is(err, Services.ErrMailNotRetryable):
+		c.Error(ctx, http.StatusConflict, err.Error())
+	case err != nil:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	default:
+		c.Success(ctx, message, "邮件已重新加入发送队列")
+	}
+}
+
+// GetSuppressions 获取邮件屏蔽列表
+// @Summary 获取邮件屏蔽列表
+// @Tags 邮件
+// @Produce json
+// @Security ApiKeyAuth
+// @Param email query string false "邮箱地址（模糊匹配）"
+// @Param page query int false "页码"
+// @Param limit query int false "每页数量"
+// @Success 200 {object} Response "屏蔽列表"
+// @Router /api/v1/mail/suppressions [get]
+func (c *MailController) GetSuppressions(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	suppressions, total, err := c.mailService.ListSuppressions(ctx.Query("email"), page, limit)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取邮件屏蔽列表失败: "+err.Error())
+		return
+	}
+
+	c.Success(ctx, gin.H{
+		"suppressions": suppressions,
+		"total":        total,
+		"page":         page,
+		"limit":        limit,
+		"total_pages":  (int(total) + limit - 1) / limit,
+	}, "邮件屏蔽列表获取成功")
+}
+
+// AddSuppression 手动将地址加入屏蔽列表
+// @Summary 添加邮件屏蔽地址
+// @Tags 邮件
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "已加入屏蔽列表"
+// @Router /api/v1/mail/suppressions [post]
+func (c *MailController) AddSuppression(ctx *gin.Context) {
+	var req struct {
+		Email  string `json:"email" binding:"required"`
+		Detail string `json:"detail"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+	address, err := mail.ParseAddress(req.Email)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, "邮箱地址无效")
+		return
+	}
+
+	if err := c.mailService.Suppress(address.Address, Models.MailSuppressionManual, Models.MailSuppressionManual, req.Detail); err != nil {
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Success(ctx, gin.H{"email": address.Address}, "已加入屏蔽列表")
+}
+
+// RemoveSuppression 将地址移出屏蔽列表
+// @Summary 移除邮件屏蔽地址
+// @Tags 邮件
+// @Produce json
+// @Security ApiKeyAuth
+// @Param email path string true "邮箱地址"
+// @Success 200 {object} Response "已移出屏蔽列表"
+// @Failure 404 {object} Response "屏蔽列表中没有该地址"
+// @Router /api/v1/mail/suppressions/{email} [delete]
+func (c *MailController) RemoveSuppression(ctx *gin.Context) {
+	err := c.mailService.Unsuppress(ctx.Param("email"))
+	switch {
+	case errors.Is(err, Services.ErrMailSuppressionNotFound):
+		c.Error(ctx, http.StatusNotFound, err.Error())
+	case err != nil:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	default:
+		c.Success(ctx, nil, "已移出屏蔽列表")
+	}
+}
+
+// HandleWebhook 接收邮件服务商的退信、投诉回调
+// @Summary 邮件退信回调
+// @Description 服务商推送退信和投诉事件，硬退信和投诉的地址加入屏蔽列表；通过 token 参数校验
+// @Tags 邮件
+// @Accept json
+// @Produce json
+// @Param provider path string true "服务商(sendgrid/mailgun/ses)"
+// @Param token query string true "回调校验令牌"
+// @Success 200 {object} Response "处理结果"
+// @Router /api/v1/mail/webhooks/{provider} [post]
+func (c *MailController) HandleWebhook(ctx *gin.Context) {
+	token := ctx.Query("token")
+	if c.webhookSecret == "" || subtle.ConstantTimeCompare([]byte(token), []byte(c.webhookSecret)) != 1 {
+		c.Error(ctx, http.StatusUnauthorized, "回调校验失败")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, 5<<20))
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, "读取回调内容失败")
+		return
+	}
+
+	suppressed, err := c.mailService.HandleBounceWebhook(ctx.Param("provider"), body)
+	switch {
+	case errors.Is(err, Services.ErrUnsupportedMailProvider):
+		c.Error(ctx, http.StatusNotFound, err.Error())
+	case err != nil:
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+	default:
+		c.Success(ctx, gin.H{"suppressed": suppressed}, fmt.Sprintf("已处理 %d 个退信地址", suppressed))
+	}
+}
@@ -1,21 +1,36 @@
 package Controllers
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Http/Middleware"
 	"cloud-platform-api/app/Models"
 	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Utils"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // MonitoringController 监控告警控制器
 type MonitoringController struct {
 	Controller
-	monitoringService *Services.OptimizedMonitoringService
+	monitoringService      *Services.OptimizedMonitoringService
+	cacheMonitoringService *Services.CacheMonitoringService
+	outboundClient         *Services.OutboundHTTPClient
+	resilienceMiddleware   *Middleware.ResilienceMiddleware
+	notificationChannels   []Services.NotificationChannel
+	alertService           *Services.AlertService
+	ingestService          *Services.MetricIngestService
+	alertWebhookService    *Services.AlertWebhookService
+	certificateCollector   *Services.CertificateExpiryCollector
 }
 
 // NewMonitoringController 创建监控告警控制器
@@ -28,7 +43,46 @@ func (c *MonitoringController) SetMonitoringService(service *Services.OptimizedM
 	c.monitoringService = service
 }
 
-// GetMetrics 获取监控指标
+// SetCacheMonitoringService 设置缓存监控服务
+func (c *MonitoringController) SetCacheMonitoringService(service *Services.CacheMonitoringService) {
+	c.cacheMonitoringService = service
+}
+
+// SetOutboundHTTPClient 设置出站HTTP客户端
+func (c *MonitoringController) SetOutboundHTTPClient(client *Services.OutboundHTTPClient) {
+	c.outboundClient = client
+}
+
+// SetResilienceMiddleware 设置弹性保护中间件
+func (c *MonitoringController) SetResilienceMiddleware(middleware *Middleware.ResilienceMiddleware) {
+	c.resilienceMiddleware = middleware
+}
+
+// SetNotificationChannels 设置告警通知通道，用于通道列表和测试发送
+func (c *MonitoringController) SetNotificationChannels(channels []Services.NotificationChannel) {
+	c.notificationChannels = channels
+}
+
+// SetAlertService 设置告警规则评估服务，用于规则回测
+func (c *MonitoringController) SetAlertService(service *Services.AlertService) {
+	c.alertService = service
+}
+
+// SetMetricIngestService 设置外部指标推送服务
+func (c *MonitoringController) SetMetricIngestService(service *Services.MetricIngestService) {
+	c.ingestService = service
+}
+
+// SetAlertWebhookService 设置外部告警接入服务
+func (c *MonitoringController) SetAlertWebhookService(service *Services.AlertWebhookService) {
+	c.alertWebhookService = service
+}
+
+// SetCertificateCollector 设置证书和域名到期采集器
+func (c *MonitoringController) SetCertificateCollector(collector *Services.CertificateExpiryCollector) {
+	c.certificateCollector = collector
+}
+
 // @Summary 获取监控指标
 // @Description 获取系统监控指标数据
 // @Tags 监控告警
@@ -40,6 +94,7 @@ func (c *MonitoringController) SetMonitoringService(service *Services.OptimizedM
 // @Param start_time query string false "开始时间" format(date-time)
 // @Param end_time query string false "结束时间" format(date-time)
 // @Success 200 {object} Response "监控指标列表"
+// @Failure 403 {object} Response "当前角色无权查看该类型的指标"
 // @Failure 500 {object} Response "服务器错误"
 // @Router /api/v1/monitoring/metrics [get]
 func (c *MonitoringController) GetMetrics(ctx *gin.Context) {
@@ -56,6 +111,11 @@ func (c *MonitoringController) GetMetrics(ctx *gin.Context) {
 	}
 	name := ctx.Query("name")
 	limitStr := ctx.DefaultQuery("limit", "100")
+	// 敏感级别按指标类型匹配，如 MONITORING_VISIBILITY_METRIC_SENSITIVITY=business=confidential
+	if !Services.DefaultMonitoringVisibility().MetricVisible(metricType, c.viewer(ctx, false)) {
+		c.Error(ctx, http.StatusForbidden, "无权查看该类型的指标")
+		return
+	}
 	// startTimeStr := ctx.Query("start_time")
 	// endTimeStr := ctx.Query("end_time")
 
@@ -78,21 +138,25 @@ func (c *MonitoringController) GetMetrics(ctx *gin.Context) {
 		"type":    metricType,
 		"name":    name,
 		"limit":   limit,
+		"labels":  c.monitoringService.DefaultTags(), // 部署标签（Kubernetes集成启用时为pod、namespace、node）
 	}, "获取监控指标成功")
 }
 
 // GetAlerts 获取告警记录
 // @Summary 获取告警记录
-// @Description 获取系统告警记录列表
+// @Description 分页获取系统告警记录，按创建时间倒序；传入 cursor 或 pagination=cursor 时使用游标分页；支持 filter[field][op]=value 筛选和 fields 选择返回字段；只返回当前角色可以查看其指标的告警
 // @Tags 监控告警
 // @Accept json
 // @Produce json
 // @Param status query string false "告警状态" Enums(active,acknowledged,resolved,suppressed)
 // @Param severity query string false "严重程度" Enums(info,warning,critical,emergency)
-// @Param limit query int false "限制返回数量" default(50)
 // @Param page query int false "页码" default(1)
-// @Param page_size query int false "每页数量" default(20)
+// @Param limit query int false "每页数量" default(20)
+// @Param cursor query string false "上一页返回的 meta.cursor"
+// @Param with_total query bool false "是否返回总数"
+// @Param fields query string false "返回字段，逗号分隔"
 // @Success 200 {object} Response "告警记录列表"
+// @Failure 400 {object} Response "游标或查询参数无效"
 // @Failure 500 {object} Response "服务器错误"
 // @Router /api/v1/monitoring/alerts [get]
 func (c *MonitoringController) GetAlerts(ctx *gin.Context) {
@@ -101,60 +165,95 @@ func (c *MonitoringController) GetAlerts(ctx *gin.Context) {
 		return
 	}
 
-	// 获取查询参数
-	status := ctx.Query("status")
-	severity := ctx.Query("severity")
-	limitStr := ctx.DefaultQuery("limit", "50")
-	pageStr := ctx.DefaultQuery("page", "1")
-	pageSizeStr := ctx.DefaultQuery("page_size", "20")
-
-	limit, err := strconv.Atoi(limitStr)
+	req, err := c.ParsePageRequest(ctx)
 	if err != nil {
-		c.Error(ctx, http.StatusBadRequest, "无效的limit参数")
+		c.Error(ctx, http.StatusBadRequest, err.Error())
 		return
 	}
-
-	page, err := strconv.Atoi(pageStr)
+	q, err := Utils.ParseListQuery(ctx.Request.URL.Query(), Services.AlertQuerySpec)
 	if err != nil {
-		c.Error(ctx, http.StatusBadRequest, "无效的page参数")
+		c.Error(ctx, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	pageSize, err := strconv.Atoi(pageSizeStr)
+	visible := Services.DefaultMonitoringVisibility().AlertFilter(c.viewer(ctx, false))
+	alerts, meta, err := c.monitoringService.ListAlerts(ctx.Query("status"), ctx.Query("severity"), q, req, visible)
+	if errors.Is(err, Utils.ErrInvalidCursor) {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
-		c.Error(ctx, http.StatusBadRequest, "无效的page_size参数")
+		c.Error(ctx, http.StatusInternalServerError, "获取告警记录失败: "+err.Error())
 		return
 	}
 
-	// 获取告警记录
-	alerts, err := c.monitoringService.GetAlerts(status, severity, limit)
+	data, err := q.Project(alerts)
 	if err != nil {
 		c.Error(ctx, http.StatusInternalServerError, "获取告警记录失败: "+err.Error())
 		return
 	}
+	c.ListSuccess(ctx, data, meta, "获取告警记录成功")
+}
 
-	// 分页处理
-	total := len(alerts)
-	start := (page - 1) * pageSize
-	end := start + pageSize
+// monitoringStreamHeartbeat 实时监控事件流的心跳间隔，短于请求超时，避免代理关闭空闲连接
+const monitoringStreamHeartbeat = 15 * time.Second
 
-	if start >= total {
-		alerts = []interface{}{}
-	} else if end > total {
-		alerts = alerts[start:total]
-	} else {
-		alerts = alerts[start:end]
+// StreamEvents 实时推送指标和告警状态变化
+// @Summary 实时推送指标和告警状态变化
+// @Description 以SSE推送每轮采集的指标值（metrics 事件）和告警触发/解决（alerts 事件），空闲时每15秒发送 ping 心跳；订阅 metrics 主题时连接建立后先推送一次最新指标值；只推送当前角色可以查看的指标和告警
+// @Tags 监控告警
+// @Produce text/event-stream
+// @Param topics query string false "主题，逗号分隔，默认全部" example(metrics,alerts)
+// @Param metrics query string false "指标名称匹配模式，逗号分隔，支持 * 通配" example(cpu_usage,memory_*)
+// @Security ApiKeyAuth
+// @Success 200 {string} string "监控事件流"
+// @Failure 400 {object} Response "主题或匹配模式无效"
+// @Router /api/v1/monitoring/stream [get]
+func (c *MonitoringController) StreamEvents(ctx *gin.Context) {
+	if c.monitoringService == nil {
+		c.Error(ctx, http.StatusInternalServerError, "监控服务未初始化")
+		return
+	}
+	filter, err := Services.ParseMonitoringStreamFilter(ctx.Query("topics"), ctx.Query("metrics"))
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
 	}
+	filter.Visible = Services.DefaultMonitoringVisibility().MetricFilter(c.viewer(ctx, false))
 
-	c.Success(ctx, gin.H{
-		"alerts":      alerts,
-		"total":       total,
-		"page":        page,
-		"page_size":   pageSize,
-		"total_pages": (total + pageSize - 1) / pageSize,
-		"status":      status,
-		"severity":    severity,
-	}, "获取告警记录成功")
+	events := c.monitoringService.Subscribe(ctx.Request.Context(), filter)
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+
+	heartbeat := time.NewTicker(monitoringStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	snapshot := Services.MonitoringStreamEvent{
+		Topic:     Services.MonitoringStreamTopicMetrics,
+		Metrics:   c.monitoringService.LatestMetrics(),
+		Timestamp: time.Now(),
+	}
+	if snapshot, ok := filter.Apply(snapshot); ok {
+		ctx.SSEvent(snapshot.Topic, snapshot)
+	}
+	ctx.Writer.Flush()
+
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			ctx.SSEvent(event.Topic, event)
+			return true
+		case <-heartbeat.C:
+			ctx.SSEvent("ping", time.Now().Unix())
+			return true
+		}
+	})
 }
 
 // AcknowledgeAlert 确认告警
@@ -257,13 +356,14 @@ func (c *MonitoringController) ResolveAlert(ctx *gin.Context) {
 
 // GetAlertRules 获取告警规则
 // @Summary 获取告警规则
-// @Description 获取系统告警规则列表
+// @Description 获取当前用户可见的告警规则：管理员可见全部规则，创建者和所属团队的成员可见自己的规则，其他用户可见 visibility 为 public 且指标可以查看的规则
 // @Tags 监控告警
 // @Accept json
 // @Produce json
 // @Param enabled query bool false "是否启用"
 // @Param type query string false "规则类型" Enums(threshold,trend,anomaly)
 // @Success 200 {object} Response "告警规则列表"
+// @Failure 400 {object} Response "参数无效"
 // @Failure 500 {object} Response "服务器错误"
 // @Router /api/v1/monitoring/alert-rules [get]
 func (c *MonitoringController) GetAlertRules(ctx *gin.Context) {
@@ -276,8 +376,35 @@ func (c *MonitoringController) GetAlertRules(ctx *gin.Context) {
 	enabledStr := ctx.Query("enabled")
 	ruleType := ctx.Query("type")
 
-	// 构建查询条件 - 暂时返回空结果
-	var rules []interface{}
+	var enabled *bool
+	if enabledStr != "" {
+		value, err := strconv.ParseBool(enabledStr)
+		if err != nil {
+			c.Error(ctx, http.StatusBadRequest, "enabled 参数无效")
+			return
+		}
+		enabled = &value
+	}
+
+	// 告警规则几乎不变，启用模型缓存时从缓存读取后按条件筛选
+	rules := []Models.AlertRule{}
+	if db := Database.GetDB(); db != nil {
+		all, err := Services.FindAlertRulesCached(db)
+		if err != nil {
+			c.Error(ctx, http.StatusInternalServerError, "获取告警规则失败: "+err.Error())
+			return
+		}
+		visibility, viewer := Services.DefaultMonitoringVisibility(), c.viewer(ctx, true)
+		for _, rule := range all {
+			if (enabled != nil && rule.Enabled != *enabled) || (ruleType != "" && rule.Type != ruleType) {
+				continue
+			}
+			if !visibility.RuleVisible(&rule, viewer) {
+				continue
+			}
+			rules = append(rules, rule)
+		}
+	}
 
 	c.Success(ctx, gin.H{
 		"alert_rules": rules,
@@ -310,14 +437,22 @@ func (c *MonitoringController) CreateAlertRule(ctx *gin.Context) {
 		return
 	}
 
-	// 获取当前用户ID
-	currentUserID, exists := ctx.Get("user_id")
-	if !exists {
+	// 获取当前用户ID，认证中间件以字符串保存用户ID
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
 		c.Error(ctx, http.StatusUnauthorized, "用户未认证")
 		return
 	}
-
-	userID := currentUserID.(uint)
+	if !c.canAssignTeam(ctx, req.TeamID) {
+		c.Error(ctx, http.StatusForbidden, "只能将告警规则归属到自己所在的团队")
+		return
+	}
+	if req.TeamID != nil && *req.TeamID == 0 {
+		req.TeamID = nil
+	}
+	if !c.canUseRuleType(ctx, req.Type) {
+		return
+	}
 
 	// 创建告警规则
 	rule := &Models.AlertRule{
@@ -339,9 +474,20 @@ func (c *MonitoringController) CreateAlertRule(ctx *gin.Context) {
 		NotificationChannels: req.NotificationChannels,
 		Tags:                 req.Tags,
 		CreatedBy:            userID,
+		TeamID:               req.TeamID,
+		Visibility:           req.Visibility,
+	}
+	if rule.Visibility == "" {
+		rule.Visibility = Models.AlertRuleVisibilityPublic
 	}
 
-	if err := c.monitoringService.CreateAlertRule(rule); err != nil {
+	// 告警规则保存到数据库，列表、更新和删除都从数据库读取
+	db := Database.GetDB()
+	if db == nil {
+		c.Error(ctx, http.StatusInternalServerError, "数据库未初始化")
+		return
+	}
+	if err := db.Create(rule).Error; err != nil {
 		c.Error(ctx, http.StatusInternalServerError, "创建告警规则失败: "+err.Error())
 		return
 	}
@@ -360,20 +506,23 @@ func (c *MonitoringController) CreateAlertRule(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path int true "告警规则ID"
-// @Param rule body UpdateAlertRuleRequest true "告警规则信息"
-// @Success 200 {object} Response "更新成功"
+// @Param If-Match header string false "期望的规则版本，即获取规则时的ETag"
+// @Param rule body UpdateAlertRuleRequest true "告警规则信息，只更新传入的字段"
+// @Success 200 {object} Response "更新成功，ETag响应头为新版本"
 // @Failure 400 {object} Response "参数错误"
+// @Failure 404 {object} Response "告警规则不存在"
+// @Failure 409 {object} Response "规则已被其他请求修改，返回当前版本"
 // @Failure 500 {object} Response "服务器错误"
 // @Router /api/v1/monitoring/alert-rules/{id} [put]
 func (c *MonitoringController) UpdateAlertRule(ctx *gin.Context) {
-	if c.monitoringService == nil {
-		c.Error(ctx, http.StatusInternalServerError, "监控服务未初始化")
+	db := Database.GetDB()
+	if db == nil {
+		c.Error(ctx, http.StatusInternalServerError, "数据库未初始化")
 		return
 	}
 
 	// 获取告警规则ID
-	ruleIDStr := ctx.Param("id")
-	_, err := strconv.ParseUint(ruleIDStr, 10, 32)
+	ruleID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
 	if err != nil {
 		c.Error(ctx, http.StatusBadRequest, "无效的告警规则ID")
 		return
@@ -384,43 +533,110 @@ func (c *MonitoringController) UpdateAlertRule(ctx *gin.Context) {
 		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
 		return
 	}
+	expectedVersion, ok := c.ExpectedVersion(ctx, req.Version)
+	if !ok {
+		return
+	}
 
-	// 暂时跳过数据库操作
-	c.Success(ctx, gin.H{
-		"message": "告警规则更新功能暂时不可用",
-	}, "告警规则更新成功")
-	return
+	var rule Models.AlertRule
+	if err := db.First(&rule, ruleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.Error(ctx, http.StatusNotFound, "告警规则不存在")
+			return
+		}
+		c.Error(ctx, http.StatusInternalServerError, "获取告警规则失败: "+err.Error())
+		return
+	}
+	if !Services.DefaultMonitoringVisibility().RuleVisible(&rule, c.viewer(ctx, true)) {
+		c.Error(ctx, http.StatusNotFound, "告警规则不存在")
+		return
+	}
+	if !c.canManageRule(ctx, &rule) {
+		c.Error(ctx, http.StatusForbidden, "无权修改该告警规则")
+		return
+	}
+	if !c.canAssignTeam(ctx, req.TeamID) {
+		c.Error(ctx, http.StatusForbidden, "只能将告警规则归属到自己所在的团队")
+		return
+	}
+	if req.Type != nil && *req.Type != rule.Type && !c.canUseRuleType(ctx, *req.Type) {
+		return
+	}
+	req.apply(&rule)
+
+	// 规则已被其他请求修改时返回409和当前版本，客户端重新获取后再提交
+	err = Models.SaveWithVersion(db, &rule, expectedVersion)
+	var conflict *Models.VersionConflictError
+	switch {
+	case errors.As(err, &conflict):
+		c.VersionConflict(ctx, conflict)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.Error(ctx, http.StatusNotFound, "告警规则不存在")
+	case err != nil:
+		c.Error(ctx, http.StatusInternalServerError, "更新告警规则失败: "+err.Error())
+	default:
+		c.SetETag(ctx, rule.Version)
+		c.Success(ctx, gin.H{
+			"rule": rule,
+		}, "告警规则更新成功")
+	}
 }
 
 // DeleteAlertRule 删除告警规则
 // @Summary 删除告警规则
-// @Description 删除指定的告警规则
+// @Description 删除指定的告警规则，告警规则进入回收站，可以由管理员恢复
 // @Tags 监控告警
 // @Accept json
 // @Produce json
 // @Param id path int true "告警规则ID"
 // @Success 200 {object} Response "删除成功"
 // @Failure 400 {object} Response "参数错误"
+// @Failure 404 {object} Response "告警规则不存在"
 // @Failure 500 {object} Response "服务器错误"
 // @Router /api/v1/monitoring/alert-rules/{id} [delete]
 func (c *MonitoringController) DeleteAlertRule(ctx *gin.Context) {
-	if c.monitoringService == nil {
-		c.Error(ctx, http.StatusInternalServerError, "监控服务未初始化")
+	db := Database.GetDB()
+	if db == nil {
+		c.Error(ctx, http.StatusInternalServerError, "数据库未初始化")
 		return
 	}
 
 	// 获取告警规则ID
-	ruleIDStr := ctx.Param("id")
-	_, err := strconv.ParseUint(ruleIDStr, 10, 32)
+	ruleID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
 	if err != nil {
 		c.Error(ctx, http.StatusBadRequest, "无效的告警规则ID")
 		return
 	}
 
-	// 暂时跳过数据库操作
-	c.Success(ctx, gin.H{
-		"message": "告警规则删除功能暂时不可用",
-	}, "告警规则删除成功")
+	var rule Models.AlertRule
+	if err := db.First(&rule, ruleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.Error(ctx, http.StatusNotFound, "告警规则不存在")
+			return
+		}
+		c.Error(ctx, http.StatusInternalServerError, "获取告警规则失败: "+err.Error())
+		return
+	}
+	if !Services.DefaultMonitoringVisibility().RuleVisible(&rule, c.viewer(ctx, true)) {
+		c.Error(ctx, http.StatusNotFound, "告警规则不存在")
+		return
+	}
+	if !c.canManageRule(ctx, &rule) {
+		c.Error(ctx, http.StatusForbidden, "无权删除该告警规则")
+		return
+	}
+
+	result := db.Delete(&Models.AlertRule{}, ruleID)
+	if result.Error != nil {
+		c.Error(ctx, http.StatusInternalServerError, "删除告警规则失败: "+result.Error.Error())
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(ctx, http.StatusNotFound, "告警规则不存在")
+		return
+	}
+
+	c.Success(ctx, gin.H{"id": ruleID}, "告警规则删除成功")
 }
 
 // GetSystemHealth 获取系统健康状态
@@ -445,13 +661,136 @@ func (c *MonitoringController) GetSystemHealth(ctx *gin.Context) {
 	}, "获取系统健康状态成功")
 }
 
+// GetCacheMetrics 获取缓存监控指标
+// @Summary 获取缓存监控指标
+// @Description 获取Redis命中率、内存、驱逐、连接数和TTL分布，以及超过阈值的告警（仅管理员）
+// @Tags 监控告警
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param refresh query bool false "是否立即重新采集" default(false)
+// @Success 200 {object} Response "缓存监控指标"
+// @Failure 503 {object} Response "缓存监控未启用"
+// @Failure 500 {object} Response "服务器错误"
+// @Router /api/v1/monitoring/cache [get]
+func (c *MonitoringController) GetCacheMetrics(ctx *gin.Context) {
+	if c.cacheMonitoringService == nil {
+		c.Error(ctx, http.StatusServiceUnavailable, "缓存监控未启用")
+		return
+	}
+
+	metrics := c.cacheMonitoringService.GetMetrics()
+	if metrics == nil || ctx.Query("refresh") == "true" {
+		var err error
+		if metrics, _, err = c.cacheMonitoringService.Check(ctx.Request.Context()); err != nil {
+			c.Error(ctx, http.StatusInternalServerError, "获取缓存监控指标失败: "+err.Error())
+			return
+		}
+	}
+
+	c.Success(ctx, gin.H{
+		"metrics": metrics,
+		"alerts":  c.cacheMonitoringService.GetAlerts(),
+	}, "获取缓存监控指标成功")
+}
+
+// GetDependencies 获取外部依赖调用统计
+// @Summary 获取外部依赖调用统计
+// @Description 获取威胁情报、Webhook等外部依赖在统计窗口内的延迟、错误率和熔断状态（仅管理员）
+// @Tags 监控告警
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "外部依赖调用统计"
+// @Router /api/v1/monitoring/dependencies [get]
+func (c *MonitoringController) GetDependencies(ctx *gin.Context) {
+	if c.outboundClient == nil {
+		c.Error(ctx, http.StatusInternalServerError, "出站HTTP客户端未初始化")
+		return
+	}
+
+	c.Success(ctx, gin.H{
+		"dependencies": c.outboundClient.Stats(),
+		"policy":       c.outboundClient.DefaultPolicy(),
+	}, "获取外部依赖调用统计成功")
+}
+
+// GetCardinality 获取指标基数统计
+// @Summary 获取指标基数统计
+// @Description 按序列数占上限的比例列出基数最高的指标，包括超过上限被拒绝或合并的采样数（仅管理员）
+// @Tags 监控告警
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param limit query int false "返回的指标数量" default(20)
+// @Success 200 {object} Response "指标基数统计"
+// @Failure 400 {object} Response "参数错误"
+// @Router /api/v1/monitoring/cardinality [get]
+func (c *MonitoringController) GetCardinality(ctx *gin.Context) {
+	if c.monitoringService == nil || c.monitoringService.CardinalityGuard() == nil {
+		c.Error(ctx, http.StatusInternalServerError, "指标基数保护未初始化")
+		return
+	}
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		c.Error(ctx, http.StatusBadRequest, "limit 必须是1到100之间的整数")
+		return
+	}
+
+	guard := c.monitoringService.CardinalityGuard()
+	c.Success(ctx, gin.H{
+		"metrics": guard.Top(limit),
+	}, "获取指标基数统计成功")
+}
+
+// GetCertificates 获取证书和域名到期状态
+// @Summary 获取证书和域名到期状态
+// @Description 获取最近一次检查的证书颁发者、SAN、有效期、证书链校验结果和域名注册到期时间，按剩余天数升序（仅管理员）
+// @Tags 监控告警
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "证书和域名到期状态"
+// @Router /api/v1/monitoring/certificates [get]
+func (c *MonitoringController) GetCertificates(ctx *gin.Context) {
+	if c.certificateCollector == nil {
+		c.Error(ctx, http.StatusInternalServerError, "证书到期监控未启用")
+		return
+	}
+
+	c.Success(ctx, gin.H{
+		"certificates": c.certificateCollector.Certificates(),
+		"domains":      c.certificateCollector.Domains(),
+		"thresholds":   c.certificateCollector.Thresholds(),
+	}, "获取证书到期状态成功")
+}
+
+// GetResilience 获取入站弹性保护状态
+// @Summary 获取入站弹性保护状态
+// @Description 获取数据库、Redis等依赖的熔断状态和各路由的并发占用、拒绝次数（仅管理员）
+// @Tags 监控告警
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "入站弹性保护状态"
+// @Router /api/v1/monitoring/resilience [get]
+func (c *MonitoringController) GetResilience(ctx *gin.Context) {
+	if c.resilienceMiddleware == nil {
+		c.Error(ctx, http.StatusInternalServerError, "弹性保护中间件未初始化")
+		return
+	}
+
+	c.Success(ctx, c.resilienceMiddleware.Stats(), "获取弹性保护状态成功")
+}
+
 // GetNotificationRecords 获取通知记录
 // @Summary 获取通知记录
 // @Description 获取系统通知发送记录
 // @Tags 监控告警
 // @Accept json
 // @Produce json
-// @Param channel query string false "通知渠道" Enums(email,webhook,slack,dingtalk,sms)
+// @Param channel query string false "通知渠道" Enums(email,webhook,slack,dingtalk,sms,telegram,wechat_work)
 // @Param status query string false "发送状态" Enums(pending,sent,failed,retrying)
 // @Param limit query int false "限制返回数量" default(50)
 // @Success 200 {object} Response "通知记录列表"
@@ -486,6 +825,330 @@ func (c *MonitoringController) GetNotificationRecords(ctx *gin.Context) {
 	}, "获取通知记录成功")
 }
 
+// GetNotificationChannels 获取告警通知通道
+// @Summary 获取告警通知通道
+// @Description 获取已注册的告警通知通道及启用状态（仅管理员）
+// @Tags 监控告警
+// @Produce json
+// @Success 200 {object} Response "通知通道列表"
+// @Router /api/v1/monitoring/notification-channels [get]
+func (c *MonitoringController) GetNotificationChannels(ctx *gin.Context) {
+	channels := make([]gin.H, 0, len(c.notificationChannels))
+	for _, channel := range c.notificationChannels {
+		channels = append(channels, gin.H{
+			"name":    channel.GetName(),
+			"enabled": channel.IsEnabled(),
+		})
+	}
+	c.Success(ctx, gin.H{"channels": channels}, "获取通知通道成功")
+}
+
+// TestNotificationChannel 测试发送告警通知
+// @Summary 测试告警通知通道
+// @Description 通过指定通道发送一条测试告警，用于验证机器人令牌、Webhook地址等配置（仅管理员）
+// @Tags 监控告警
+// @Produce json
+// @Param channel path string true "通知通道" Enums(webhook,sms,telegram,wechat_work)
+// @Success 200 {object} Response "发送成功"
+// @Failure 400 {object} Response "通道未启用"
+// @Failure 404 {object} Response "通道不存在"
+// @Failure 429 {object} Response "触发限流"
+// @Failure 502 {object} Response "发送失败"
+// @Router /api/v1/monitoring/notification-channels/{channel}/test [post]
+func (c *MonitoringController) TestNotificationChannel(ctx *gin.Context) {
+	name := ctx.Param("channel")
+	var channel Services.NotificationChannel
+	for _, candidate := range c.notificationChannels {
+		if candidate.GetName() == name {
+			channel = candidate
+			break
+		}
+	}
+	if channel == nil {
+		c.Error(ctx, http.StatusNotFound, "通知通道不存在: "+name)
+		return
+	}
+	if !channel.IsEnabled() {
+		c.Error(ctx, http.StatusBadRequest, "通知通道未启用或配置不完整: "+name)
+		return
+	}
+
+	alert := Services.MonitoringAlert{
+		ID:        fmt.Sprintf("test_%d", time.Now().UnixNano()),
+		Type:      "notification_test",
+		Severity:  "info",
+		Title:     "告警通知测试",
+		Message:   "这是一条测试告警，收到即表示通知通道配置正确。",
+		Source:    "monitoring",
+		Timestamp: time.Now(),
+	}
+	if userID, err := c.GetCurrentUser(ctx); err == nil {
+		alert.Metadata = map[string]interface{}{"requested_by": userID}
+	}
+
+	if err := channel.SendAlert(alert); err != nil {
+		var rateLimitErr *Services.NotificationRateLimitError
+		if errors.As(err, &rateLimitErr) {
+			c.TooManyRequests(ctx, err.Error(), int(rateLimitErr.RetryAfter.Round(time.Second)/time.Second))
+			return
+		}
+		c.Error(ctx, http.StatusBadGateway, "测试通知发送失败: "+err.Error())
+		return
+	}
+	c.Success(ctx, gin.H{"channel": name, "alert_id": alert.ID}, "测试通知已发送")
+}
+
+// BacktestAlertRule 告警规则回测
+// @Summary 告警规则回测
+// @Description 使用指标历史回放告警规则，返回过去一段时间（默认30天）内规则会触发的告警，支持固定阈值和动态阈值规则（仅管理员）
+// @Tags 监控告警
+// @Accept json
+// @Produce json
+// @Param rule body BacktestAlertRuleRequest true "回测规则"
+// @Success 200 {object} Response "回测结果"
+// @Failure 400 {object} Response "参数错误"
+// @Failure 503 {object} Response "指标历史未启用"
+// @Router /api/v1/monitoring/alert-rules/backtest [post]
+func (c *MonitoringController) BacktestAlertRule(ctx *gin.Context) {
+	if c.alertService == nil {
+		c.Error(ctx, http.StatusServiceUnavailable, "告警服务未初始化")
+		return
+	}
+
+	var req BacktestAlertRuleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+
+	if req.Type != Services.AlertRuleTypeDynamic && req.Condition == "" {
+		c.Error(ctx, http.StatusBadRequest, "固定阈值规则需要指定比较条件")
+		return
+	}
+	durations := make(map[string]time.Duration)
+	for name, value := range map[string]string{"duration": req.Duration, "seasonality": req.Seasonality, "window": req.Window} {
+		if value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			c.Error(ctx, http.StatusBadRequest, fmt.Sprintf("无效的时长 %s: %s", name, value))
+			return
+		}
+		durations[name] = parsed
+	}
+
+	rule := &Services.AlertRule{
+		ID:        "backtest",
+		Name:      "规则回测",
+		Metric:    req.Metric,
+		Type:      req.Type,
+		Condition: req.Condition,
+		Threshold: req.Threshold,
+		Duration:  durations["duration"],
+	}
+	if req.Type == Services.AlertRuleTypeDynamic {
+		rule.Dynamic = &Services.DynamicThreshold{
+			Sensitivity: req.Sensitivity,
+			Seasonality: durations["seasonality"],
+			Periods:     req.Periods,
+			Window:      durations["window"],
+			Direction:   req.Direction,
+			MinSamples:  req.MinSamples,
+		}
+	}
+
+	days := req.Days
+	if days == 0 {
+		days = 30
+	}
+	end := time.Now()
+	start := end.AddDate(0, 0, -days)
+
+	result, err := c.alertService.BacktestRule(rule, start, end)
+	if err != nil {
+		if errors.Is(err, Services.ErrMetricHistoryUnavailable) {
+			c.Error(ctx, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		c.Error(ctx, http.StatusBadRequest, "回测失败: "+err.Error())
+		return
+	}
+
+	c.Success(ctx, gin.H{"rule": rule, "result": result}, "规则回测完成")
+}
+
+// IngestMetrics 接收外部推送的指标
+// @Summary 推送指标
+// @Description 批处理任务和其他服务推送指标，使用来源令牌认证（Authorization: Bearer <令牌> 或 X-Ingest-Token）。按 Content-Type 识别格式：application/json、text/plain（Influx行协议）、application/x-protobuf（Prometheus remote-write），也可通过 format 参数指定
+// @Tags 监控告警
+// @Accept json
+// @Produce json
+// @Param format query string false "数据格式" Enums(json,influx,prometheus_remote_write)
+// @Param precision query string false "Influx时间戳精度" Enums(ns,us,ms,s)
+// @Success 200 {object} Response "接收结果"
+// @Failure 400 {object} Response "格式错误"
+// @Failure 401 {object} Response "令牌无效"
+// @Failure 413 {object} Response "请求过大"
+// @Failure 429 {object} Response "超过推送限额"
+// @Router /api/v1/monitoring/ingest [post]
+func (c *MonitoringController) IngestMetrics(ctx *gin.Context) {
+	if c.ingestService == nil {
+		c.Error(ctx, http.StatusServiceUnavailable, "指标推送未启用")
+		return
+	}
+
+	source, err := c.ingestService.Authenticate(ingestToken(ctx))
+	if err != nil {
+		c.Error(ctx, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	format := ctx.Query("format")
+	if format == "" {
+		format = ingestFormat(ctx.ContentType())
+	}
+
+	maxBodySize := c.ingestService.MaxBodySize()
+	body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxBodySize+1))
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, "读取请求体失败")
+		return
+	}
+	if int64(len(body)) > maxBodySize {
+		c.Error(ctx, http.StatusRequestEntityTooLarge, fmt.Sprintf("请求体超过 %d 字节", maxBodySize))
+		return
+	}
+
+	samples, err := c.ingestService.Parse(format, body, ctx.Query("precision"))
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, "解析指标失败: "+err.Error())
+		return
+	}
+
+	result, err := c.ingestService.Ingest(source, samples)
+	if err != nil {
+		var quotaErr *Services.MetricIngestQuotaError
+		var usageErr *Services.UsageQuotaError
+		switch {
+		case errors.As(err, &quotaErr):
+			c.TooManyRequests(ctx, err.Error(), int((quotaErr.RetryAfter+time.Second-1)/time.Second))
+		case errors.As(err, &usageErr):
+			Middleware.AbortWithQuotaExceeded(ctx, usageErr)
+		case errors.Is(err, Services.ErrIngestBatchTooLarge):
+			c.Error(ctx, http.StatusRequestEntityTooLarge, err.Error())
+		default:
+			c.Error(ctx, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+
+	c.Success(ctx, result, "指标接收完成")
+}
+
+// ReceiveAlertmanagerWebhook 接收 Alertmanager 推送的告警
+// @Summary 接收Alertmanager告警
+// @Description 接收 Prometheus Alertmanager webhook（version 4）推送的告警，使用来源令牌认证（Authorization: Bearer <令牌>）。告警名称取 alertname 标签，严重程度取 severity 标签，内容取 summary 或 description 注解；接入的告警与规则告警一样去重、发送通知并归入事件
+// @Tags 监控告警
+// @Accept json
+// @Produce json
+// @Success 200 {object} Response "接收结果"
+// @Failure 400 {object} Response "格式错误"
+// @Failure 401 {object} Response "令牌无效"
+// @Failure 413 {object} Response "请求过大或告警过多"
+// @Router /api/v1/monitoring/alert-webhooks/alertmanager [post]
+func (c *MonitoringController) ReceiveAlertmanagerWebhook(ctx *gin.Context) {
+	c.receiveAlertWebhook(ctx, func(body []byte) ([]Services.ExternalAlert, error) {
+		return c.alertWebhookService.ParseAlertmanager(body)
+	})
+}
+
+// ReceiveJSONAlertWebhook 接收通用JSON格式推送的告警
+// @Summary 接收通用JSON告警
+// @Description 按 MONITORING_ALERT_WEBHOOK_MAPPING_* 配置的字段路径解析告警，请求体可以是单个告警、告警数组或包含告警数组的对象，使用来源令牌认证
+// @Tags 监控告警
+// @Accept json
+// @Produce json
+// @Success 200 {object} Response "接收结果"
+// @Failure 400 {object} Response "格式错误"
+// @Failure 401 {object} Response "令牌无效"
+// @Failure 413 {object} Response "请求过大或告警过多"
+// @Router /api/v1/monitoring/alert-webhooks/json [post]
+func (c *MonitoringController) ReceiveJSONAlertWebhook(ctx *gin.Context) {
+	c.receiveAlertWebhook(ctx, func(body []byte) ([]Services.ExternalAlert, error) {
+		return c.alertWebhookService.ParseJSON(body)
+	})
+}
+
+// receiveAlertWebhook 认证来源、读取请求体并接收解析出的告警
+func (c *MonitoringController) receiveAlertWebhook(ctx *gin.Context, parse func(body []byte) ([]Services.ExternalAlert, error)) {
+	if c.alertWebhookService == nil {
+		c.Error(ctx, http.StatusServiceUnavailable, "外部告警接入未启用")
+		return
+	}
+
+	source, err := c.alertWebhookService.Authenticate(ingestToken(ctx))
+	if err != nil {
+		c.Error(ctx, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	maxBodySize := c.alertWebhookService.MaxBodySize()
+	body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxBodySize+1))
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, "读取请求体失败")
+		return
+	}
+	if int64(len(body)) > maxBodySize {
+		c.Error(ctx, http.StatusRequestEntityTooLarge, fmt.Sprintf("请求体超过 %d 字节", maxBodySize))
+		return
+	}
+
+	alerts, err := parse(body)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := c.alertWebhookService.Receive(source, alerts)
+	if err != nil {
+		if errors.Is(err, Services.ErrAlertWebhookTooManyAlerts) {
+			c.Error(ctx, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.Success(ctx, result, "告警接收完成")
+}
+
+// ingestToken 从请求头读取推送令牌，兼容 Bearer 和 Influx 客户端的 Token 前缀
+func ingestToken(ctx *gin.Context) string {
+	if token := ctx.GetHeader("X-Ingest-Token"); token != "" {
+		return token
+	}
+	auth := ctx.GetHeader("Authorization")
+	for _, prefix := range []string{"Bearer ", "Token "} {
+		if strings.HasPrefix(auth, prefix) {
+			return strings.TrimPrefix(auth, prefix)
+		}
+	}
+	return ""
+}
+
+// ingestFormat 按 Content-Type 识别推送格式
+func ingestFormat(contentType string) string {
+	switch contentType {
+	case "application/x-protobuf":
+		return Services.IngestFormatRemoteWrite
+	case "text/plain":
+		return Services.IngestFormatInflux
+	default:
+		return Services.IngestFormatJSON
+	}
+}
+
 // GetMonitoringStats 获取监控统计信息
 // @Summary 获取监控统计信息
 // @Description 获取监控系统统计信息
@@ -553,25 +1216,126 @@ type CreateAlertRuleRequest struct {
 	MaxEscalationLevel   int     `json:"max_escalation_level"`
 	NotificationChannels string  `json:"notification_channels"`
 	Tags                 string  `json:"tags"`
+	TeamID               *uint   `json:"team_id"`                                          // 所属团队，只能指定自己所在的团队
+	Visibility           string  `json:"visibility" binding:"omitempty,oneof=public team"` // 可见范围，默认 public
 }
 
-// UpdateAlertRuleRequest 更新告警规则请求
+// UpdateAlertRuleRequest 更新告警规则请求，为 nil 的字段保持不变
 type UpdateAlertRuleRequest struct {
-	Name                 string  `json:"name"`
-	Description          string  `json:"description"`
-	Type                 string  `json:"type"`
-	MetricType           string  `json:"metric_type"`
-	MetricName           string  `json:"metric_name"`
-	Condition            string  `json:"condition"`
-	Threshold            float64 `json:"threshold"`
-	Duration             int     `json:"duration"`
-	Severity             string  `json:"severity"`
-	Enabled              bool    `json:"enabled"`
-	Suppression          bool    `json:"suppression"`
-	SuppressionWindow    int     `json:"suppression_window"`
-	Escalation           bool    `json:"escalation"`
-	EscalationDelay      int     `json:"escalation_delay"`
-	MaxEscalationLevel   int     `json:"max_escalation_level"`
-	NotificationChannels string  `json:"notification_channels"`
-	Tags                 string  `json:"tags"`
+	Name                 *string  `json:"name"`
+	Description          *string  `json:"description"`
+	Type                 *string  `json:"type"`
+	MetricType           *string  `json:"metric_type"`
+	MetricName           *string  `json:"metric_name"`
+	Condition            *string  `json:"condition"`
+	Threshold            *float64 `json:"threshold"`
+	Duration             *int     `json:"duration"`
+	Severity             *string  `json:"severity"`
+	Enabled              *bool    `json:"enabled"`
+	Suppression          *bool    `json:"suppression"`
+	SuppressionWindow    *int     `json:"suppression_window"`
+	Escalation           *bool    `json:"escalation"`
+	EscalationDelay      *int     `json:"escalation_delay"`
+	MaxEscalationLevel   *int     `json:"max_escalation_level"`
+	NotificationChannels *string  `json:"notification_channels"`
+	Tags                 *string  `json:"tags"`
+	TeamID               *uint    `json:"team_id"`                                          // 所属团队，为0时取消归属
+	Visibility           *string  `json:"visibility" binding:"omitempty,oneof=public team"` // 可见范围
+	Version              uint     `json:"version"`                                          // 期望的规则版本，可用 If-Match 请求头代替
+}
+
+// apply 把请求中传入的字段写入规则
+func (r *UpdateAlertRuleRequest) apply(rule *Models.AlertRule) {
+	setIfPresent(&rule.Name, r.Name)
+	setIfPresent(&rule.Description, r.Description)
+	setIfPresent(&rule.Type, r.Type)
+	setIfPresent(&rule.MetricType, r.MetricType)
+	setIfPresent(&rule.MetricName, r.MetricName)
+	setIfPresent(&rule.Condition, r.Condition)
+	setIfPresent(&rule.Threshold, r.Threshold)
+	setIfPresent(&rule.Duration, r.Duration)
+	setIfPresent(&rule.Severity, r.Severity)
+	setIfPresent(&rule.Enabled, r.Enabled)
+	setIfPresent(&rule.Suppression, r.Suppression)
+	setIfPresent(&rule.SuppressionWindow, r.SuppressionWindow)
+	setIfPresent(&rule.Escalation, r.Escalation)
+	setIfPresent(&rule.EscalationDelay, r.EscalationDelay)
+	setIfPresent(&rule.MaxEscalationLevel, r.MaxEscalationLevel)
+	setIfPresent(&rule.NotificationChannels, r.NotificationChannels)
+	setIfPresent(&rule.Tags, r.Tags)
+	setIfPresent(&rule.Visibility, r.Visibility)
+	if r.TeamID != nil {
+		rule.TeamID = r.TeamID
+		if *r.TeamID == 0 {
+			rule.TeamID = nil
+		}
+	}
+}
+
+// teamActor 当前用户，用于检查告警规则的团队权限
+func (c *MonitoringController) teamActor(ctx *gin.Context) Services.TeamActor {
+	userID, _ := c.GetCurrentUser(ctx)
+	return Services.TeamActor{UserID: userID, Role: c.GetCurrentUserRole(ctx)}
+}
+
+// viewer 当前用户，用于按角色裁剪指标和告警；withTeams 为 true 时查询所在团队，用于检查告警规则的可见性
+func (c *MonitoringController) viewer(ctx *gin.Context, withTeams bool) Services.DashboardViewer {
+	userID, _ := c.GetCurrentUser(ctx)
+	viewer := Services.DashboardViewer{UserID: userID, Role: c.GetCurrentUserRole(ctx)}
+	if withTeams && userID != 0 {
+		if teams := Services.DefaultTeamService(Database.GetDB()); teams != nil {
+			viewer.Teams = teams.Memberships(userID)
+		}
+	}
+	return viewer
+}
+
+// canAssignTeam 当前用户能否把告警规则归属到团队，teamID 为空或0时不检查
+func (c *MonitoringController) canAssignTeam(ctx *gin.Context, teamID *uint) bool {
+	if teamID == nil || *teamID == 0 {
+		return true
+	}
+	teams := Services.DefaultTeamService(Database.GetDB())
+	return teams != nil && teams.CanAssign(*teamID, c.teamActor(ctx))
+}
+
+// canManageRule 当前用户能否修改告警规则：不属于团队的规则所有登录用户都可以修改，团队规则只有管理员、创建者和团队成员可以修改
+func (c *MonitoringController) canManageRule(ctx *gin.Context, rule *Models.AlertRule) bool {
+	if rule.TeamID == nil {
+		return true
+	}
+	teams := Services.DefaultTeamService(Database.GetDB())
+	return teams != nil && teams.CanManageResource(rule.TeamID, rule.CreatedBy, c.teamActor(ctx))
+}
+
+// canUseRuleType 异常检测和动态阈值规则需要套餐包含异常检测功能，不包含时返回402
+func (c *MonitoringController) canUseRuleType(ctx *gin.Context, ruleType string) bool {
+	if ruleType != "anomaly" && ruleType != Services.AlertRuleTypeDynamic {
+		return true
+	}
+	return Middleware.NewPlanFeatureMiddleware(nil).Check(ctx, Services.PlanFeatureAnomalyDetection)
+}
+
+// setIfPresent value 不为 nil 时写入 dst
+func setIfPresent[T any](dst *T, value *T) {
+	if value != nil {
+		*dst = *value
+	}
+}
+
+// BacktestAlertRuleRequest 告警规则回测请求
+// 时长参数使用 Go 时长格式，例如 5m、1h、168h
+type BacktestAlertRuleRequest struct {
+	Metric      string  `json:"metric" binding:"required"`
+	Type        string  `json:"type" binding:"omitempty,oneof=static dynamic"`
+	Condition   string  `json:"condition" binding:"omitempty,oneof=> >= < <= == !="`
+	Threshold   float64 `json:"threshold"`
+	Duration    string  `json:"duration"`                                // 持续满足时长
+	Days        int     `json:"days" binding:"omitempty,min=1,max=90"`   // 回测天数，默认30
+	Sensitivity float64 `json:"sensitivity" binding:"omitempty,gt=0"`    // 动态阈值标准差倍数，默认3
+	Seasonality string  `json:"seasonality"`                             // 基线周期，默认168h
+	Periods     int     `json:"periods" binding:"omitempty,min=1,max=8"` // 参与计算的历史周期数，默认1
+	Window      string  `json:"window"`                                  // 取样窗口，默认1h
+	Direction   string  `json:"direction" binding:"omitempty,oneof=above below both"`
+	MinSamples  int     `json:"min_samples" binding:"omitempty,min=1"`
 }
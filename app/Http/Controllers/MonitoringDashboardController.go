@@ -0,0 +1,395 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Services"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MonitoringDashboardController 监控仪表板和组件控制器
+type MonitoringDashboardController struct {
+	Controller
+	dashboardService *Services.MonitoringDashboardService
+}
+
+// NewMonitoringDashboardController 创建监控仪表板控制器
+func NewMonitoringDashboardController(dashboardService *Services.MonitoringDashboardService) *MonitoringDashboardController {
+	return &MonitoringDashboardController{dashboardService: dashboardService}
+}
+
+// GetDashboards 获取仪表板列表
+// @Summary 获取仪表板列表
+// @Description 获取当前用户可见的仪表板：自己创建的、公开的和共享给当前角色的，管理员可见全部
+// @Tags 监控仪表板
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "仪表板列表"
+// @Router /api/v1/monitoring/dashboards [get]
+func (c *MonitoringDashboardController) GetDashboards(ctx *gin.Context) {
+	viewer, ok := c.viewer(ctx)
+	if !ok {
+		return
+	}
+	dashboards, err := c.dashboardService.ListDashboards(viewer)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取仪表板失败: "+err.Error())
+		return
+	}
+	c.Success(ctx, dashboards, "仪表板获取成功")
+}
+
+// GetDashboard 获取仪表板详情
+// @Summary 获取仪表板详情
+// @Description 获取仪表板及其全部组件
+// @Tags 监控仪表板
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "仪表板ID"
+// @Success 200 {object} Response "仪表板"
+// @Failure 404 {object} Response "仪表板不存在"
+// @Router /api/v1/monitoring/dashboards/{id} [get]
+func (c *MonitoringDashboardController) GetDashboard(ctx *gin.Context) {
+	viewer, ok := c.viewer(ctx)
+	if !ok {
+		return
+	}
+	id, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	dashboard, err := c.dashboardService.GetDashboard(id, viewer)
+	if err != nil {
+		c.dashboardError(ctx, err)
+		return
+	}
+	c.Success(ctx, dashboard, "仪表板获取成功")
+}
+
+// CreateDashboard 创建仪表板
+// @Summary 创建仪表板
+// @Description 创建仪表板，当前用户为创建者；设为默认仪表板需要管理员权限
+// @Tags 监控仪表板
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param dashboard body Services.MonitoringDashboardInput true "仪表板"
+// @Success 201 {object} Response "创建的仪表板"
+// @Failure 409 {object} Response "名称已存在"
+// @Router /api/v1/monitoring/dashboards [post]
+func (c *MonitoringDashboardController) CreateDashboard(ctx *gin.Context) {
+	viewer, ok := c.viewer(ctx)
+	if !ok {
+		return
+	}
+	var input Services.MonitoringDashboardInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	dashboard, err := c.dashboardService.CreateDashboard(input, viewer)
+	if err != nil {
+		c.dashboardError(ctx, err)
+		return
+	}
+	c.Created(ctx, dashboard, "仪表板已创建")
+}
+
+// UpdateDashboard 更新仪表板
+// @Summary 更新仪表板
+// @Description 更新仪表板名称、布局、刷新间隔和共享设置，只有创建者和管理员可以修改
+// @Tags 监控仪表板
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "仪表板ID"
+// @Param dashboard body Services.MonitoringDashboardInput true "仪表板"
+// @Success 200 {object} Response "更新后的仪表板"
+// @Failure 403 {object} Response "无权修改"
+// @Router /api/v1/monitoring/dashboards/{id} [put]
+func (c *MonitoringDashboardController) UpdateDashboard(ctx *gin.Context) {
+	viewer, ok := c.viewer(ctx)
+	if !ok {
+		return
+	}
+	id, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	var input Services.MonitoringDashboardInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	dashboard, err := c.dashboardService.UpdateDashboard(id, input, viewer)
+	if err != nil {
+		c.dashboardError(ctx, err)
+		return
+	}
+	c.Success(ctx, dashboard, "仪表板已更新")
+}
+
+// DeleteDashboard 删除仪表板
+// @Summary 删除仪表板
+// @Description 删除仪表板及其全部组件，只有创建者和管理员可以删除
+// @Tags 监控仪表板
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "仪表板ID"
+// @Success 200 {object} Response "删除成功"
+// @Router /api/v1/monitoring/dashboards/{id} [delete]
+func (c *MonitoringDashboardController) DeleteDashboard(ctx *gin.Context) {
+	viewer, ok := c.viewer(ctx)
+	if !ok {
+		return
+	}
+	id, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	if err := c.dashboardService.DeleteDashboard(id, viewer); err != nil {
+		c.dashboardError(ctx, err)
+		return
+	}
+	c.Success(ctx, nil, "仪表板已删除")
+}
+
+// ExportDashboard 导出仪表板
+// @Summary 导出仪表板
+// @Description 导出仪表板及其组件为JSON，可在其他环境导入
+// @Tags 监控仪表板
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "仪表板ID"
+// @Success 200 {object} Services.MonitoringDashboardExport "导出内容"
+// @Router /api/v1/monitoring/dashboards/{id}/export [get]
+func (c *MonitoringDashboardController) ExportDashboard(ctx *gin.Context) {
+	viewer, ok := c.viewer(ctx)
+	if !ok {
+		return
+	}
+	id, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	export, err := c.dashboardService.ExportDashboard(id, viewer)
+	if err != nil {
+		c.dashboardError(ctx, err)
+		return
+	}
+	// 直接返回导出内容，便于保存为文件后原样导入
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="dashboard-%d.json"`, id))
+	ctx.JSON(http.StatusOK, export)
+}
+
+// ImportDashboard 导入仪表板
+// @Summary 导入仪表板
+// @Description 导入导出的仪表板JSON，当前用户为创建者；同名仪表板已存在时可通过 name 参数指定新名称
+// @Tags 监控仪表板
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param name query string false "导入后的名称"
+// @Param dashboard body Services.MonitoringDashboardExport true "导出内容"
+// @Success 201 {object} Response "导入的仪表板"
+// @Failure 409 {object} Response "名称已存在"
+// @Router /api/v1/monitoring/dashboards/import [post]
+func (c *MonitoringDashboardController) ImportDashboard(ctx *gin.Context) {
+	viewer, ok := c.viewer(ctx)
+	if !ok {
+		return
+	}
+	var export Services.MonitoringDashboardExport
+	if err := ctx.ShouldBindJSON(&export); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	dashboard, err := c.dashboardService.ImportDashboard(export, ctx.Query("name"), viewer)
+	if err != nil {
+		c.dashboardError(ctx, err)
+		return
+	}
+	c.Created(ctx, dashboard, "仪表板已导入")
+}
+
+// CreateWidget 添加组件
+// @Summary 添加组件
+// @Description 向仪表板添加组件，指定图表类型、数据源、查询、阈值和布局
+// @Tags 监控仪表板
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "仪表板ID"
+// @Param widget body Services.MonitoringWidgetInput true "组件"
+// @Success 201 {object} Response "创建的组件"
+// @Router /api/v1/monitoring/dashboards/{id}/widgets [post]
+func (c *MonitoringDashboardController) CreateWidget(ctx *gin.Context) {
+	viewer, ok := c.viewer(ctx)
+	if !ok {
+		return
+	}
+	dashboardID, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	var input Services.MonitoringWidgetInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	widget, err := c.dashboardService.CreateWidget(dashboardID, input, viewer)
+	if err != nil {
+		c.dashboardError(ctx, err)
+		return
+	}
+	c.Created(ctx, widget, "组件已添加")
+}
+
+// UpdateWidget 更新组件
+// @Summary 更新组件
+// @Tags 监控仪表板
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "仪表板ID"
+// @Param widget_id path int true "组件ID"
+// @Param widget body Services.MonitoringWidgetInput true "组件"
+// @Success 200 {object} Response "更新后的组件"
+// @Router /api/v1/monitoring/dashboards/{id}/widgets/{widget_id} [put]
+func (c *MonitoringDashboardController) UpdateWidget(ctx *gin.Context) {
+	viewer, ok := c.viewer(ctx)
+	if !ok {
+		return
+	}
+	dashboardID, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	widgetID, ok := c.pathID(ctx, "widget_id")
+	if !ok {
+		return
+	}
+	var input Services.MonitoringWidgetInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	widget, err := c.dashboardService.UpdateWidget(dashboardID, widgetID, input, viewer)
+	if err != nil {
+		c.dashboardError(ctx, err)
+		return
+	}
+	c.Success(ctx, widget, "组件已更新")
+}
+
+// DeleteWidget 删除组件
+// @Summary 删除组件
+// @Tags 监控仪表板
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "仪表板ID"
+// @Param widget_id path int true "组件ID"
+// @Success 200 {object} Response "删除成功"
+// @Router /api/v1/monitoring/dashboards/{id}/widgets/{widget_id} [delete]
+func (c *MonitoringDashboardController) DeleteWidget(ctx *gin.Context) {
+	viewer, ok := c.viewer(ctx)
+	if !ok {
+		return
+	}
+	dashboardID, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	widgetID, ok := c.pathID(ctx, "widget_id")
+	if !ok {
+		return
+	}
+	if err := c.dashboardService.DeleteWidget(dashboardID, widgetID, viewer); err != nil {
+		c.dashboardError(ctx, err)
+		return
+	}
+	c.Success(ctx, nil, "组件已删除")
+}
+
+// GetWidgetData 获取组件数据
+// @Summary 获取组件数据
+// @Description 在服务端执行组件保存的查询：metric 返回最新指标值，metric_history 返回降采样后的时间序列，alerts 返回告警列表；设置了阈值时返回各指标的状态
+// @Tags 监控仪表板
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "仪表板ID"
+// @Param widget_id path int true "组件ID"
+// @Success 200 {object} Response "组件数据"
+// @Failure 402 {object} Response "查询范围超过套餐允许的历史指标范围"
+// @Failure 503 {object} Response "指标历史未启用"
+// @Router /api/v1/monitoring/dashboards/{id}/widgets/{widget_id}/data [get]
+func (c *MonitoringDashboardController) GetWidgetData(ctx *gin.Context) {
+	viewer, ok := c.viewer(ctx)
+	if !ok {
+		return
+	}
+	dashboardID, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	widgetID, ok := c.pathID(ctx, "widget_id")
+	if !ok {
+		return
+	}
+	data, err := c.dashboardService.WidgetData(dashboardID, widgetID, viewer)
+	if err != nil {
+		c.dashboardError(ctx, err)
+		return
+	}
+	c.Success(ctx, data, "组件数据获取成功")
+}
+
+// viewer 获取当前用户，未登录时返回401
+func (c *MonitoringDashboardController) viewer(ctx *gin.Context) (Services.DashboardViewer, bool) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Error(ctx, http.StatusUnauthorized, "需要登录")
+		return Services.DashboardViewer{}, false
+	}
+	viewer := Services.DashboardViewer{UserID: userID, Role: c.GetCurrentUserRole(ctx)}
+	if teams := Services.DefaultTeamService(Database.GetDB()); teams != nil {
+		viewer.Teams = teams.Memberships(userID)
+	}
+	return viewer, true
+}
+
+// pathID 解析路径中的ID参数
+func (c *MonitoringDashboardController) pathID(ctx *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param(name), 10, 32)
+	if err != nil || id == 0 {
+		c.Error(ctx, http.StatusBadRequest, "无效的ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// dashboardError 按错误类型返回响应
+func (c *MonitoringDashboardController) dashboardError(ctx *gin.Context, err error) {
+	var planErr *Services.PlanFeatureError
+	switch {
+	case errors.As(err, &planErr):
+		Middleware.AbortWithPlanFeatureRequired(ctx, planErr)
+	case errors.Is(err, Services.ErrDashboardNotFound), errors.Is(err, Services.ErrWidgetNotFound):
+		c.Error(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, Services.ErrDashboardForbidden):
+		c.Error(ctx, http.StatusForbidden, err.Error())
+	case errors.Is(err, Services.ErrDashboardNameExists):
+		c.Error(ctx, http.StatusConflict, err.Error())
+	case errors.Is(err, Services.ErrInvalidDashboard):
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+	case errors.Is(err, Services.ErrMetricHistoryUnavailable):
+		c.Error(ctx, http.StatusServiceUnavailable, err.Error())
+	default:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,317 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MonitoringIncidentController 事件控制器
+type MonitoringIncidentController struct {
+	Controller
+	incidentService *Services.MonitoringIncidentService
+}
+
+// NewMonitoringIncidentController 创建事件控制器
+func NewMonitoringIncidentController(incidentService *Services.MonitoringIncidentService) *MonitoringIncidentController {
+	return &MonitoringIncidentController{incidentService: incidentService}
+}
+
+// IncidentQuerySpec 事件列表可筛选、排序和返回的字段
+var IncidentQuerySpec = Utils.QuerySpec{
+	Fields: map[string]Utils.QueryField{
+		"id":              {Column: "id", Type: Utils.QueryInt, Filter: true, Sort: true},
+		"title":           {Column: "title", Type: Utils.QueryString, Filter: true},
+		"rule_id":         {Column: "rule_id", Type: Utils.QueryString, Filter: true},
+		"severity":        {Column: "severity", Type: Utils.QueryString, Filter: true, Sort: true},
+		"status":          {Column: "status", Type: Utils.QueryString, Filter: true, Sort: true},
+		"alert_count":     {Column: "alert_count", Type: Utils.QueryInt, Filter: true, Sort: true},
+		"active_alerts":   {Column: "active_alerts", Type: Utils.QueryInt, Filter: true},
+		"reopen_count":    {Column: "reopen_count", Type: Utils.QueryInt, Filter: true},
+		"opened_at":       {Column: "opened_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+		"acknowledged_at": {Column: "acknowledged_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+		"acknowledged_by": {Column: "acknowledged_by", Type: Utils.QueryInt, Filter: true},
+		"resolved_at":     {Column: "resolved_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+		"resolved_by":     {Column: "resolved_by", Type: Utils.QueryInt, Filter: true},
+		"resolution_note": {Column: "resolution_note", Type: Utils.QueryString},
+		"created_at":      {Column: "created_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+		"updated_at":      {Column: "updated_at", Type: Utils.QueryTime},
+	},
+	DefaultSort: "-opened_at",
+}
+
+// incidentNoteRequest 确认、评论和解决的说明
+type incidentNoteRequest struct {
+	Message string `json:"message"`
+}
+
+// GetIncidents 获取事件列表
+// @Summary 获取事件列表
+// @Description 分页查询告警归并的事件，支持 filter[status][eq]=open 等筛选
+// @Tags 事件管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param filter[status][eq] query string false "按状态筛选(open/acknowledged/resolved)"
+// @Param sort query string false "排序字段，逗号分隔，前缀-表示降序" default(-opened_at)
+// @Param fields query string false "返回字段，逗号分隔"
+// @Param page query int false "页码" default(1)
+// @Param limit query int false "每页数量" default(20)
+// @Param cursor query string false "上一页返回的 meta.cursor，传入时使用游标分页"
+// @Success 200 {object} Response "事件列表"
+// @Failure 400 {object} Response "查询参数无效"
+// @Router /api/v1/monitoring/incidents [get]
+func (c *MonitoringIncidentController) GetIncidents(ctx *gin.Context) {
+	req, err := c.ParsePageRequest(ctx)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	q, err := Utils.ParseListQuery(ctx.Request.URL.Query(), IncidentQuerySpec)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	incidents, meta, err := c.incidentService.ListIncidents(q, req)
+	if errors.Is(err, Utils.ErrInvalidCursor) || errors.Is(err, Utils.ErrInvalidQuery) {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取事件列表失败: "+err.Error())
+		return
+	}
+
+	data, err := q.Project(incidents)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取事件列表失败: "+err.Error())
+		return
+	}
+	c.ListSuccess(ctx, data, meta, "事件列表获取成功")
+}
+
+// GetIncident 获取事件详情
+// @Summary 获取事件详情
+// @Description 获取事件及其时间线，时间线按时间正序
+// @Tags 事件管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "事件ID"
+// @Success 200 {object} Response "事件详情"
+// @Failure 404 {object} Response "事件不存在"
+// @Router /api/v1/monitoring/incidents/{id} [get]
+func (c *MonitoringIncidentController) GetIncident(ctx *gin.Context) {
+	id, ok := c.pathID(ctx)
+	if !ok {
+		return
+	}
+	detail, err := c.incidentService.GetIncident(id)
+	if err != nil {
+		c.incidentError(ctx, err)
+		return
+	}
+	c.Success(ctx, detail, "事件获取成功")
+}
+
+// AcknowledgeIncident 确认事件
+// @Summary 确认事件
+// @Description 确认事件，首次确认时间用于统计MTTA（仅管理员）
+// @Tags 事件管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "事件ID"
+// @Param request body incidentNoteRequest false "确认说明"
+// @Success 200 {object} Response "确认后的事件"
+// @Failure 404 {object} Response "事件不存在"
+// @Failure 409 {object} Response "事件已确认或已解决"
+// @Router /api/v1/monitoring/incidents/{id}/acknowledge [post]
+func (c *MonitoringIncidentController) AcknowledgeIncident(ctx *gin.Context) {
+	id, userID, req, ok := c.noteRequest(ctx, false)
+	if !ok {
+		return
+	}
+	detail, err := c.incidentService.Acknowledge(id, userID, req.Message)
+	if err != nil {
+		c.incidentError(ctx, err)
+		return
+	}
+	c.Success(ctx, detail, "事件已确认")
+}
+
+// CommentIncident 添加事件评论
+// @Summary 添加事件评论
+// @Description 在事件时间线中添加评论，已解决的事件也可以补充评论
+// @Tags 事件管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "事件ID"
+// @Param request body incidentNoteRequest true "评论内容"
+// @Success 201 {object} Response "时间线记录"
+// @Failure 400 {object} Response "评论内容无效"
+// @Failure 404 {object} Response "事件不存在"
+// @Router /api/v1/monitoring/incidents/{id}/comments [post]
+func (c *MonitoringIncidentController) CommentIncident(ctx *gin.Context) {
+	id, userID, req, ok := c.noteRequest(ctx, true)
+	if !ok {
+		return
+	}
+	event, err := c.incidentService.AddComment(id, userID, req.Message)
+	if err != nil {
+		c.incidentError(ctx, err)
+		return
+	}
+	c.Created(ctx, event, "评论已添加")
+}
+
+// ResolveIncident 解决事件
+// @Summary 解决事件
+// @Description 解决事件并记录解决说明；已解决（包括自动解决）的事件只更新解决说明（仅管理员）
+// @Tags 事件管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "事件ID"
+// @Param request body incidentNoteRequest false "解决说明"
+// @Success 200 {object} Response "解决后的事件"
+// @Failure 404 {object} Response "事件不存在"
+// @Failure 409 {object} Response "事件已解决"
+// @Router /api/v1/monitoring/incidents/{id}/resolve [post]
+func (c *MonitoringIncidentController) ResolveIncident(ctx *gin.Context) {
+	id, userID, req, ok := c.noteRequest(ctx, false)
+	if !ok {
+		return
+	}
+	detail, err := c.incidentService.Resolve(id, userID, req.Message)
+	if err != nil {
+		c.incidentError(ctx, err)
+		return
+	}
+	c.Success(ctx, detail, "事件已解决")
+}
+
+// GetIncidentStats 获取事件统计
+// @Summary 获取事件统计
+// @Description 统计时间范围内打开的事件数量、状态分布和MTTA/MTTR（秒），默认最近30天
+// @Tags 事件管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param from query string false "开始时间(RFC3339)"
+// @Param to query string false "结束时间(RFC3339)"
+// @Success 200 {object} Response "事件统计"
+// @Failure 400 {object} Response "时间格式无效"
+// @Router /api/v1/monitoring/incidents/stats [get]
+func (c *MonitoringIncidentController) GetIncidentStats(ctx *gin.Context) {
+	to := time.Now()
+	from := to.AddDate(0, 0, -30)
+	for name, target := range map[string]*time.Time{"from": &from, "to": &to} {
+		if value := ctx.Query(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.Error(ctx, http.StatusBadRequest, fmt.Sprintf("%s 时间格式无效，应为RFC3339", name))
+				return
+			}
+			*target = parsed
+		}
+	}
+	if !from.Before(to) {
+		c.Error(ctx, http.StatusBadRequest, "开始时间必须早于结束时间")
+		return
+	}
+
+	stats, err := c.incidentService.Stats(from, to)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取事件统计失败: "+err.Error())
+		return
+	}
+	c.Success(ctx, stats, "事件统计获取成功")
+}
+
+// GetIncidentReview 导出事件复盘
+// @Summary 导出事件复盘
+// @Description 导出事件复盘时间线，包含确认和解决耗时、涉及的告警和完整时间线；format=markdown 时下载Markdown文档
+// @Tags 事件管理
+// @Produce json
+// @Produce text/markdown
+// @Security ApiKeyAuth
+// @Param id path int true "事件ID"
+// @Param format query string false "导出格式" Enums(json,markdown) default(json)
+// @Success 200 {object} Response "事件复盘"
+// @Failure 400 {object} Response "格式无效"
+// @Failure 404 {object} Response "事件不存在"
+// @Router /api/v1/monitoring/incidents/{id}/review [get]
+func (c *MonitoringIncidentController) GetIncidentReview(ctx *gin.Context) {
+	id, ok := c.pathID(ctx)
+	if !ok {
+		return
+	}
+	format := ctx.DefaultQuery("format", "json")
+	if format != "json" && format != "markdown" {
+		c.Error(ctx, http.StatusBadRequest, "导出格式无效，可选值为 json、markdown")
+		return
+	}
+
+	review, err := c.incidentService.Review(id)
+	if err != nil {
+		c.incidentError(ctx, err)
+		return
+	}
+	if format == "markdown" {
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="incident-%d-review.md"`, id))
+		ctx.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(review.Markdown()))
+		return
+	}
+	c.Success(ctx, review, "事件复盘获取成功")
+}
+
+// noteRequest 解析事件ID、当前用户和说明，required 为 true 时必须提供请求体
+func (c *MonitoringIncidentController) noteRequest(ctx *gin.Context, required bool) (uint, uint, incidentNoteRequest, bool) {
+	var req incidentNoteRequest
+	id, ok := c.pathID(ctx)
+	if !ok {
+		return 0, 0, req, false
+	}
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "需要登录")
+		return 0, 0, req, false
+	}
+	if required || ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+			return 0, 0, req, false
+		}
+	}
+	return id, userID, req, true
+}
+
+// pathID 解析路径中的事件ID
+func (c *MonitoringIncidentController) pathID(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		c.Error(ctx, http.StatusBadRequest, "无效的事件ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// incidentError 按错误类型返回响应
+func (c *MonitoringIncidentController) incidentError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, Services.ErrIncidentNotFound):
+		c.Error(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, Services.ErrIncidentResolved), errors.Is(err, Services.ErrIncidentAcknowledged):
+		c.Error(ctx, http.StatusConflict, err.Error())
+	case errors.Is(err, Services.ErrInvalidIncidentComment):
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+	default:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,237 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Services"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MonitoringReportController 定时监控报告控制器
+type MonitoringReportController struct {
+	Controller
+	reportService *Services.MonitoringReportService
+}
+
+// NewMonitoringReportController 创建定时监控报告控制器
+func NewMonitoringReportController(reportService *Services.MonitoringReportService) *MonitoringReportController {
+	return &MonitoringReportController{reportService: reportService}
+}
+
+// GetReports 获取报告列表
+// @Summary 获取报告列表
+// @Description 获取全部报告定义及其调度（仅管理员）
+// @Tags 监控报告
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "报告列表"
+// @Router /api/v1/monitoring/reports [get]
+func (c *MonitoringReportController) GetReports(ctx *gin.Context) {
+	reports, err := c.reportService.ListReports()
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取报告失败: "+err.Error())
+		return
+	}
+	c.Success(ctx, reports, "报告获取成功")
+}
+
+// GetReport 获取报告详情
+// @Summary 获取报告详情
+// @Tags 监控报告
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "报告ID"
+// @Success 200 {object} Response "报告"
+// @Failure 404 {object} Response "报告不存在"
+// @Router /api/v1/monitoring/reports/{id} [get]
+func (c *MonitoringReportController) GetReport(ctx *gin.Context) {
+	id, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	report, err := c.reportService.GetReport(id)
+	if err != nil {
+		c.reportError(ctx, err)
+		return
+	}
+	c.Success(ctx, report, "报告获取成功")
+}
+
+// CreateReport 创建报告
+// @Summary 创建报告
+// @Description 创建日报、周报、月报或自定义报告；未指定调度时日报、周报、月报分别在每天、每周一、每月1日8点生成
+// @Tags 监控报告
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param report body Services.MonitoringReportInput true "报告"
+// @Success 201 {object} Response "创建的报告"
+// @Failure 400 {object} Response "参数无效"
+// @Router /api/v1/monitoring/reports [post]
+func (c *MonitoringReportController) CreateReport(ctx *gin.Context) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Error(ctx, http.StatusUnauthorized, "需要登录")
+		return
+	}
+	var input Services.MonitoringReportInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	report, err := c.reportService.CreateReport(input, userID)
+	if err != nil {
+		c.reportError(ctx, err)
+		return
+	}
+	c.Created(ctx, report, "报告已创建")
+}
+
+// UpdateReport 更新报告
+// @Summary 更新报告
+// @Tags 监控报告
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "报告ID"
+// @Param report body Services.MonitoringReportInput true "报告"
+// @Success 200 {object} Response "更新后的报告"
+// @Router /api/v1/monitoring/reports/{id} [put]
+func (c *MonitoringReportController) UpdateReport(ctx *gin.Context) {
+	id, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	var input Services.MonitoringReportInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	report, err := c.reportService.UpdateReport(id, input)
+	if err != nil {
+		c.reportError(ctx, err)
+		return
+	}
+	c.Success(ctx, report, "报告已更新")
+}
+
+// DeleteReport 删除报告
+// @Summary 删除报告
+// @Description 删除报告定义、调度和全部历史报告文件
+// @Tags 监控报告
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "报告ID"
+// @Success 200 {object} Response "删除成功"
+// @Router /api/v1/monitoring/reports/{id} [delete]
+func (c *MonitoringReportController) DeleteReport(ctx *gin.Context) {
+	id, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	if err := c.reportService.DeleteReport(id); err != nil {
+		c.reportError(ctx, err)
+		return
+	}
+	c.Success(ctx, nil, "报告已删除")
+}
+
+// GenerateReport 立即生成报告
+// @Summary 立即生成报告
+// @Description 按当前时间计算统计周期生成报告并投递给接收者
+// @Tags 监控报告
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "报告ID"
+// @Success 201 {object} Response "生成的报告文件"
+// @Router /api/v1/monitoring/reports/{id}/generate [post]
+func (c *MonitoringReportController) GenerateReport(ctx *gin.Context) {
+	id, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	file, err := c.reportService.Generate(id, Services.ReportTriggerManual, time.Now())
+	if err != nil {
+		c.reportError(ctx, err)
+		return
+	}
+	c.Created(ctx, file, "报告已生成")
+}
+
+// GetReportFiles 获取历史报告
+// @Summary 获取历史报告
+// @Description 分页获取报告已生成的文件，最新的在前
+// @Tags 监控报告
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "报告ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Success 200 {object} Response "历史报告列表"
+// @Router /api/v1/monitoring/reports/{id}/files [get]
+func (c *MonitoringReportController) GetReportFiles(ctx *gin.Context) {
+	id, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	page, pageSize := c.ValidatePagination(ctx)
+	files, total, err := c.reportService.ListFiles(id, page, pageSize)
+	if err != nil {
+		c.reportError(ctx, err)
+		return
+	}
+	c.PaginatedSuccess(ctx, files, total, page, pageSize, "历史报告获取成功")
+}
+
+// DownloadReportFile 下载历史报告
+// @Summary 下载历史报告
+// @Tags 监控报告
+// @Produce application/pdf,text/html,application/json
+// @Security ApiKeyAuth
+// @Param id path int true "报告ID"
+// @Param file_id path int true "报告文件ID"
+// @Success 200 {file} file "报告文件"
+// @Failure 404 {object} Response "报告文件不存在"
+// @Router /api/v1/monitoring/reports/{id}/files/{file_id}/download [get]
+func (c *MonitoringReportController) DownloadReportFile(ctx *gin.Context) {
+	id, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	fileID, ok := c.pathID(ctx, "file_id")
+	if !ok {
+		return
+	}
+	file, contentType, err := c.reportService.GetFile(id, fileID)
+	if err != nil {
+		c.reportError(ctx, err)
+		return
+	}
+	ctx.Header("Content-Type", contentType)
+	ctx.FileAttachment(file.FilePath, file.FileName)
+}
+
+// pathID 解析路径中的ID参数
+func (c *MonitoringReportController) pathID(ctx *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param(name), 10, 32)
+	if err != nil || id == 0 {
+		c.Error(ctx, http.StatusBadRequest, "无效的ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// reportError 按错误类型返回响应
+func (c *MonitoringReportController) reportError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, Services.ErrReportNotFound), errors.Is(err, Services.ErrReportFileNotFound):
+		c.Error(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, Services.ErrInvalidReport):
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+	default:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,204 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Services"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MonitoringSLOController 服务等级目标控制器
+type MonitoringSLOController struct {
+	Controller
+	sloService *Services.MonitoringSLOService
+}
+
+// NewMonitoringSLOController 创建服务等级目标控制器
+func NewMonitoringSLOController(sloService *Services.MonitoringSLOService) *MonitoringSLOController {
+	return &MonitoringSLOController{sloService: sloService}
+}
+
+// GetSLOs 获取SLO列表
+// @Summary 获取SLO列表
+// @Tags 服务等级目标
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "SLO列表"
+// @Router /api/v1/monitoring/slos [get]
+func (c *MonitoringSLOController) GetSLOs(ctx *gin.Context) {
+	slos, err := c.sloService.ListSLOs()
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取SLO失败: "+err.Error())
+		return
+	}
+	c.Success(ctx, slos, "SLO获取成功")
+}
+
+// GetSLO 获取SLO详情
+// @Summary 获取SLO详情
+// @Tags 服务等级目标
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "SLO ID"
+// @Success 200 {object} Response "SLO"
+// @Failure 404 {object} Response "SLO不存在"
+// @Router /api/v1/monitoring/slos/{id} [get]
+func (c *MonitoringSLOController) GetSLO(ctx *gin.Context) {
+	id, ok := c.pathID(ctx)
+	if !ok {
+		return
+	}
+	slo, err := c.sloService.GetSLO(id)
+	if err != nil {
+		c.sloError(ctx, err)
+		return
+	}
+	c.Success(ctx, slo, "SLO获取成功")
+}
+
+// CreateSLO 创建SLO
+// @Summary 创建SLO
+// @Description 创建可用性或延迟SLO，同时注册快速和慢速错误预算消耗告警规则（仅管理员）
+// @Tags 服务等级目标
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param slo body Services.SLOInput true "SLO"
+// @Success 201 {object} Response "创建的SLO"
+// @Failure 400 {object} Response "参数无效"
+// @Failure 409 {object} Response "名称已存在"
+// @Router /api/v1/monitoring/slos [post]
+func (c *MonitoringSLOController) CreateSLO(ctx *gin.Context) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Error(ctx, http.StatusUnauthorized, "需要登录")
+		return
+	}
+	var input Services.SLOInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	slo, err := c.sloService.CreateSLO(input, userID)
+	if err != nil {
+		c.sloError(ctx, err)
+		return
+	}
+	c.Created(ctx, slo, "SLO已创建")
+}
+
+// UpdateSLO 更新SLO
+// @Summary 更新SLO
+// @Description 更新SLO并同步其消耗告警规则（仅管理员）
+// @Tags 服务等级目标
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "SLO ID"
+// @Param slo body Services.SLOInput true "SLO"
+// @Success 200 {object} Response "更新后的SLO"
+// @Router /api/v1/monitoring/slos/{id} [put]
+func (c *MonitoringSLOController) UpdateSLO(ctx *gin.Context) {
+	id, ok := c.pathID(ctx)
+	if !ok {
+		return
+	}
+	var input Services.SLOInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	slo, err := c.sloService.UpdateSLO(id, input)
+	if err != nil {
+		c.sloError(ctx, err)
+		return
+	}
+	c.Success(ctx, slo, "SLO已更新")
+}
+
+// DeleteSLO 删除SLO
+// @Summary 删除SLO
+// @Description 删除SLO并移除其消耗告警规则（仅管理员）
+// @Tags 服务等级目标
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "SLO ID"
+// @Success 200 {object} Response "删除成功"
+// @Router /api/v1/monitoring/slos/{id} [delete]
+func (c *MonitoringSLOController) DeleteSLO(ctx *gin.Context) {
+	id, ok := c.pathID(ctx)
+	if !ok {
+		return
+	}
+	if err := c.sloService.DeleteSLO(id); err != nil {
+		c.sloError(ctx, err)
+		return
+	}
+	c.Success(ctx, nil, "SLO已删除")
+}
+
+// GetSLOStatuses 获取全部SLO达成情况
+// @Summary 获取全部SLO达成情况
+// @Description 计算各SLO滚动窗口内的达成率、剩余错误预算和各窗口的预算消耗速率
+// @Tags 服务等级目标
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "SLO达成情况"
+// @Router /api/v1/monitoring/slos/status [get]
+func (c *MonitoringSLOController) GetSLOStatuses(ctx *gin.Context) {
+	statuses, err := c.sloService.Statuses(time.Now())
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "计算SLO失败: "+err.Error())
+		return
+	}
+	c.Success(ctx, statuses, "SLO达成情况获取成功")
+}
+
+// GetSLOStatus 获取单个SLO达成情况
+// @Summary 获取单个SLO达成情况
+// @Tags 服务等级目标
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "SLO ID"
+// @Success 200 {object} Response "SLO达成情况"
+// @Failure 404 {object} Response "SLO不存在"
+// @Router /api/v1/monitoring/slos/{id}/status [get]
+func (c *MonitoringSLOController) GetSLOStatus(ctx *gin.Context) {
+	id, ok := c.pathID(ctx)
+	if !ok {
+		return
+	}
+	status, err := c.sloService.Status(id, time.Now())
+	if err != nil {
+		c.sloError(ctx, err)
+		return
+	}
+	c.Success(ctx, status, "SLO达成情况获取成功")
+}
+
+// pathID 解析路径中的SLO ID
+func (c *MonitoringSLOController) pathID(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		c.Error(ctx, http.StatusBadRequest, "无效的SLO ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// sloError 按错误类型返回响应
+func (c *MonitoringSLOController) sloError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, Services.ErrSLONotFound):
+		c.Error(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, Services.ErrSLONameExists):
+		c.Error(ctx, http.StatusConflict, err.Error())
+	case errors.Is(err, Services.ErrInvalidSLO):
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+	default:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+}
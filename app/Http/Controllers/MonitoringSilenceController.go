@@ -0,0 +1,318 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Utils"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MonitoringSilenceController 告警静默和维护窗口控制器
+type MonitoringSilenceController struct {
+	Controller
+	silenceService *Services.AlertSilenceService
+}
+
+// NewMonitoringSilenceController 创建告警静默控制器
+func NewMonitoringSilenceController(silenceService *Services.AlertSilenceService) *MonitoringSilenceController {
+	return &MonitoringSilenceController{silenceService: silenceService}
+}
+
+// SilenceQuerySpec 静默列表可筛选、排序和返回的字段
+var SilenceQuerySpec = Utils.QuerySpec{
+	Fields: map[string]Utils.QueryField{
+		"id":                    {Column: "id", Type: Utils.QueryInt, Filter: true, Sort: true},
+		"matchers":              {Column: "matchers", Type: Utils.QueryString},
+		"starts_at":             {Column: "starts_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+		"ends_at":               {Column: "ends_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+		"created_by":            {Column: "created_by", Type: Utils.QueryInt, Filter: true},
+		"comment":               {Column: "comment", Type: Utils.QueryString, Filter: true},
+		"maintenance_window_id": {Column: "maintenance_window_id", Type: Utils.QueryInt, Filter: true},
+		"status":                {Column: "status", Type: Utils.QueryString},
+		"created_at":            {Column: "created_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+		"updated_at":            {Column: "updated_at", Type: Utils.QueryTime},
+	},
+	DefaultSort: "-created_at",
+}
+
+// MaintenanceWindowQuerySpec 维护窗口列表可筛选、排序和返回的字段
+var MaintenanceWindowQuerySpec = Utils.QuerySpec{
+	Fields: map[string]Utils.QueryField{
+		"id":               {Column: "id", Type: Utils.QueryInt, Filter: true, Sort: true},
+		"name":             {Column: "name", Type: Utils.QueryString, Filter: true, Sort: true},
+		"description":      {Column: "description", Type: Utils.QueryString},
+		"matchers":         {Column: "matchers", Type: Utils.QueryString},
+		"starts_at":        {Column: "starts_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+		"ends_at":          {Column: "ends_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+		"schedule":         {Column: "schedule", Type: Utils.QueryString, Filter: true},
+		"duration_minutes": {Column: "duration_minutes", Type: Utils.QueryInt},
+		"created_by":       {Column: "created_by", Type: Utils.QueryInt, Filter: true},
+		"created_at":       {Column: "created_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+		"updated_at":       {Column: "updated_at", Type: Utils.QueryTime},
+	},
+	DefaultSort: "-created_at",
+}
+
+// GetSilences 获取静默列表
+// @Summary 获取静默列表
+// @Description 分页查询告警静默，state 按状态筛选（pending/active/expired），支持 filter[maintenance_window_id]=1 等筛选
+// @Tags 告警静默
+// @Produce json
+// @Security ApiKeyAuth
+// @Param state query string false "按状态筛选(pending/active/expired)"
+// @Param filter[created_by] query int false "按创建者筛选"
+// @Param sort query string false "排序字段，逗号分隔，前缀-表示降序" default(-created_at)
+// @Param fields query string false "返回字段，逗号分隔"
+// @Param page query int false "页码" default(1)
+// @Param limit query int false "每页数量" default(20)
+// @Param cursor query string false "上一页返回的 meta.cursor，传入时使用游标分页"
+// @Success 200 {object} Response "静默列表"
+// @Failure 400 {object} Response "查询参数无效"
+// @Router /api/v1/monitoring/silences [get]
+func (c *MonitoringSilenceController) GetSilences(ctx *gin.Context) {
+	req, err := c.ParsePageRequest(ctx)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	q, err := Utils.ParseListQuery(ctx.Request.URL.Query(), SilenceQuerySpec)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	silences, meta, err := c.silenceService.ListSilences(q, req, ctx.Query("state"))
+	if errors.Is(err, Utils.ErrInvalidCursor) || errors.Is(err, Utils.ErrInvalidQuery) || errors.Is(err, Services.ErrInvalidSilence) {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取静默列表失败: "+err.Error())
+		return
+	}
+
+	data, err := q.Project(silences)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取静默列表失败: "+err.Error())
+		return
+	}
+	c.ListSuccess(ctx, data, meta, "静默列表获取成功")
+}
+
+// CreateSilence 创建静默
+// @Summary 创建静默
+// @Description 创建告警静默，开始到结束时间内标签满足全部匹配器的告警不发送通知，匹配器语义与 Alertmanager 相同（仅管理员）
+// @Tags 告警静默
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param silence body Services.SilenceInput true "静默"
+// @Success 201 {object} Response "创建的静默"
+// @Failure 400 {object} Response "参数无效"
+// @Router /api/v1/monitoring/silences [post]
+func (c *MonitoringSilenceController) CreateSilence(ctx *gin.Context) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Error(ctx, http.StatusUnauthorized, "需要登录")
+		return
+	}
+	var input Services.SilenceInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	silence, err := c.silenceService.CreateSilence(input, userID)
+	if err != nil {
+		c.silenceError(ctx, err)
+		return
+	}
+	c.Created(ctx, silence, "静默已创建")
+}
+
+// GetSilence 获取静默详情
+// @Summary 获取静默详情
+// @Tags 告警静默
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "静默ID"
+// @Success 200 {object} Response "静默详情"
+// @Failure 404 {object} Response "静默不存在"
+// @Router /api/v1/monitoring/silences/{id} [get]
+func (c *MonitoringSilenceController) GetSilence(ctx *gin.Context) {
+	id, ok := c.pathID(ctx)
+	if !ok {
+		return
+	}
+	silence, err := c.silenceService.GetSilence(id)
+	if err != nil {
+		c.silenceError(ctx, err)
+		return
+	}
+	c.Success(ctx, silence, "静默获取成功")
+}
+
+// ExpireSilence 过期静默
+// @Summary 过期静默
+// @Description 立即过期静默，结束时间改为当前时间，未开始的静默结束时间改为开始时间，记录保留（仅管理员）
+// @Tags 告警静默
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "静默ID"
+// @Success 200 {object} Response "过期后的静默"
+// @Failure 404 {object} Response "静默不存在"
+// @Failure 409 {object} Response "静默已过期"
+// @Router /api/v1/monitoring/silences/{id} [delete]
+func (c *MonitoringSilenceController) ExpireSilence(ctx *gin.Context) {
+	id, ok := c.pathID(ctx)
+	if !ok {
+		return
+	}
+	silence, err := c.silenceService.ExpireSilence(id)
+	if err != nil {
+		c.silenceError(ctx, err)
+		return
+	}
+	c.Success(ctx, silence, "静默已过期")
+}
+
+// GetMaintenanceWindows 获取维护窗口列表
+// @Summary 获取维护窗口列表
+// @Tags 告警静默
+// @Produce json
+// @Security ApiKeyAuth
+// @Param sort query string false "排序字段，逗号分隔，前缀-表示降序" default(-created_at)
+// @Param page query int false "页码" default(1)
+// @Param limit query int false "每页数量" default(20)
+// @Success 200 {object} Response "维护窗口列表"
+// @Failure 400 {object} Response "查询参数无效"
+// @Router /api/v1/monitoring/maintenance-windows [get]
+func (c *MonitoringSilenceController) GetMaintenanceWindows(ctx *gin.Context) {
+	req, err := c.ParsePageRequest(ctx)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	q, err := Utils.ParseListQuery(ctx.Request.URL.Query(), MaintenanceWindowQuerySpec)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	windows, meta, err := c.silenceService.ListMaintenanceWindows(q, req)
+	if errors.Is(err, Utils.ErrInvalidCursor) || errors.Is(err, Utils.ErrInvalidQuery) {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取维护窗口列表失败: "+err.Error())
+		return
+	}
+
+	data, err := q.Project(windows)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取维护窗口列表失败: "+err.Error())
+		return
+	}
+	c.ListSuccess(ctx, data, meta, "维护窗口列表获取成功")
+}
+
+// CreateMaintenanceWindow 创建维护窗口
+// @Summary 创建维护窗口
+// @Description 创建单次（starts_at/ends_at）或按cron计划重复（schedule/duration_minutes）的维护窗口，窗口时段自动创建关联的静默（仅管理员）
+// @Tags 告警静默
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param window body Services.MaintenanceWindowInput true "维护窗口"
+// @Success 201 {object} Response "创建的维护窗口"
+// @Failure 400 {object} Response "参数无效"
+// @Router /api/v1/monitoring/maintenance-windows [post]
+func (c *MonitoringSilenceController) CreateMaintenanceWindow(ctx *gin.Context) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Error(ctx, http.StatusUnauthorized, "需要登录")
+		return
+	}
+	var input Services.MaintenanceWindowInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	window, err := c.silenceService.CreateMaintenanceWindow(input, userID)
+	if err != nil {
+		c.silenceError(ctx, err)
+		return
+	}
+	c.Created(ctx, window, "维护窗口已创建")
+}
+
+// GetMaintenanceWindow 获取维护窗口详情
+// @Summary 获取维护窗口详情
+// @Tags 告警静默
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "维护窗口ID"
+// @Success 200 {object} Response "维护窗口详情"
+// @Failure 404 {object} Response "维护窗口不存在"
+// @Router /api/v1/monitoring/maintenance-windows/{id} [get]
+func (c *MonitoringSilenceController) GetMaintenanceWindow(ctx *gin.Context) {
+	id, ok := c.pathID(ctx)
+	if !ok {
+		return
+	}
+	window, err := c.silenceService.GetMaintenanceWindow(id)
+	if err != nil {
+		c.silenceError(ctx, err)
+		return
+	}
+	c.Success(ctx, window, "维护窗口获取成功")
+}
+
+// DeleteMaintenanceWindow 删除维护窗口
+// @Summary 删除维护窗口
+// @Description 删除维护窗口并过期其关联的静默（仅管理员）
+// @Tags 告警静默
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "维护窗口ID"
+// @Success 200 {object} Response "删除成功"
+// @Failure 404 {object} Response "维护窗口不存在"
+// @Router /api/v1/monitoring/maintenance-windows/{id} [delete]
+func (c *MonitoringSilenceController) DeleteMaintenanceWindow(ctx *gin.Context) {
+	id, ok := c.pathID(ctx)
+	if !ok {
+		return
+	}
+	if err := c.silenceService.DeleteMaintenanceWindow(id); err != nil {
+		c.silenceError(ctx, err)
+		return
+	}
+	c.Success(ctx, nil, "维护窗口已删除")
+}
+
+// pathID 解析路径中的ID
+func (c *MonitoringSilenceController) pathID(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		c.Error(ctx, http.StatusBadRequest, "无效的ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// silenceError 按错误类型返回响应
+func (c *MonitoringSilenceController) silenceError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, Services.ErrSilenceNotFound), errors.Is(err, Services.ErrMaintenanceWindowNotFound):
+		c.Error(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, Services.ErrSilenceExpired):
+		c.Error(ctx, http.StatusConflict, err.Error())
+	case errors.Is(err, Services.ErrInvalidSilence), errors.Is(err, Services.ErrInvalidMaintenanceWindow):
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+	default:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,312 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Services"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// NotificationController 站内通知控制器
+//
+// 功能说明：
+// 1. 当前用户的通知列表、未读数、标记已读和删除
+// 2. 实时推送：通过SSE或WebSocket推送新通知和未读数变化
+//
+// 安全特性：
+// - 所有接口都需要登录（在路由中配置），只能访问自己的通知
+type NotificationController struct {
+	Controller
+	notificationService *Services.NotificationService
+	upgrader            websocket.Upgrader
+}
+
+// NewNotificationController 创建站内通知控制器
+func NewNotificationController(notificationService *Services.NotificationService) *NotificationController {
+	return &NotificationController{
+		notificationService: notificationService,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 4096,
+		},
+	}
+}
+
+// GetNotifications 获取当前用户的通知
+// @Summary 获取通知列表
+// @Description 按类型和已读状态筛选当前用户的通知，同时返回未读数
+// @Tags 站内通知
+// @Produce json
+// @Security ApiKeyAuth
+// @Param type query string false "通知类型(system/backup/security_alert/password_expiring)"
+// @Param unread query bool false "只返回未读通知"
+// @Param page query int false "页码"
+// @Param limit query int false "每页数量"
+// @Success 200 {object} Response "通知列表"
+// @Router /api/v1/notifications [get]
+func (c *NotificationController) GetNotifications(ctx *gin.Context) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "需要登录")
+		return
+	}
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	unreadOnly, _ := strconv.ParseBool(ctx.Query("unread"))
+
+	notifications, total, err := c.notificationService.List(userID, Services.NotificationFilter{
+		Type:       ctx.Query("type"),
+		UnreadOnly: unreadOnly,
+		Page:       page,
+		Limit:      limit,
+	})
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取通知失败: "+err.Error())
+		return
+	}
+	unread, err := c.notificationService.UnreadCount(userID)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取未读通知数失败: "+err.Error())
+		return
+	}
+
+	c.Success(ctx, gin.H{
+		"notifications": notifications,
+		"unread_count":  unread,
+		"total":         total,
+		"page":          page,
+		"limit":         limit,
+		"total_pages":   (int(total) + limit - 1) / limit,
+	}, "通知获取成功")
+}
+
+// GetUnreadCount 获取未读通知数
+// @Summary 获取未读通知数
+// @Tags 站内通知
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "未读通知数"
+// @Router /api/v1/notifications/unread-count [get]
+func (c *NotificationController) GetUnreadCount(ctx *gin.Context) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "需要登录")
+		return
+	}
+
+	unread, err := c.notificationService.UnreadCount(userID)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取未读通知数失败: "+err.Error())
+		return
+	}
+	c.Success(ctx, gin.H{"unread_count": unread}, "未读通知数获取成功")
+}
+
+// MarkRead 标记通知已读
+// @Summary 标记通知已读
+// @Tags 站内通知
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "通知ID"
+// @Success 200 {object} Response "已读的通知"
+// @Failure 404 {object} Response "通知不存在"
+// @Router /api/v1/notifications/{id}/read [post]
+func (c *NotificationController) MarkRead(ctx *gin.Context) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "需要登录")
+		return
+	}
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, "通知ID无效")
+		return
+	}
+
+	notification, err := c.notificationService.MarkRead(userID, uint(id))
+	switch {
+	case errors.Is(err, Services.ErrNotificationNotFound):
+		c.Error(ctx, http.StatusNotFound, err.Error())
+	case err != nil:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	default:
+		c.Success(ctx, notification, "通知已标记为已读")
+	}
+}
+
+// MarkAllRead 标记全部通知已读
+// @Summary 全部标记已读
+// @Description 标记当前用户的全部未读通知为已读，可通过 type 参数只标记某一类型
+// @Tags 站内通知
+// @Produce json
+// @Security ApiKeyAuth
+// @Param type query string false "通知类型"
+// @Success 200 {object} Response "标记数量"
+// @Router /api/v1/notifications/read-all [post]
+func (c *NotificationController) MarkAllRead(ctx *gin.Context) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "需要登录")
+		return
+	}
+
+	updated, err := c.notificationService.MarkAllRead(userID, ctx.Query("type"))
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Success(ctx, gin.H{"updated": updated}, fmt.Sprintf("已将 %d 条通知标记为已读", updated))
+}
+
+// DeleteNotification 删除通知
+// @Summary 删除通知
+// @Tags 站内通知
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "通知ID"
+// @Success 200 {object} Response "删除成功"
+// @Failure 404 {object} Response "通知不存在"
+// @Router /api/v1/notifications/{id} [delete]
+func (c *NotificationController) DeleteNotification(ctx *gin.Context) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "需要登录")
+		return
+	}
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, "通知ID无效")
+		return
+	}
+
+	err = c.notificationService.Delete(userID, uint(id))
+	switch {
+	case errors.Is(err, Services.ErrNotificationNotFound):
+		c.Error(ctx, http.StatusNotFound, err.Error())
+	case err != nil:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	default:
+		c.Success(ctx, nil, "通知已删除")
+	}
+}
+
+// Stream 实时推送通知
+// @Summary 实时推送通知
+// @Description 推送新通知和未读数变化。默认使用SSE，请求头包含WebSocket升级时使用WebSocket；连接建立后先推送一次当前未读数
+// @Tags 站内通知
+// @Produce text/event-stream
+// @Security ApiKeyAuth
+// @Success 200 {string} string "通知事件流"
+// @Failure 404 {object} Response "未启用实时推送"
+// @Router /api/v1/notifications/stream [get]
+func (c *NotificationController) Stream(ctx *gin.Context) {
+	if !c.notificationService.GetConfig().PushEnabled {
+		c.Error(ctx, http.StatusNotFound, "未启用通知实时推送")
+		return
+	}
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "需要登录")
+		return
+	}
+
+	if websocket.IsWebSocketUpgrade(ctx.Request) {
+		c.streamWebSocket(ctx, userID)
+		return
+	}
+	c.streamSSE(ctx, userID)
+}
+
+// streamSSE 通过SSE推送通知，每30秒发送一次心跳
+func (c *NotificationController) streamSSE(ctx *gin.Context, userID uint) {
+	events := c.notificationService.Subscribe(ctx.Request.Context(), userID)
+	unread, _ := c.notificationService.UnreadCount(userID)
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+
+	heartbeat := time.NewTicker(30 * time.Second)
+	defer heartbeat.Stop()
+
+	ctx.SSEvent(Services.NotificationEventUnreadCount, Services.NotificationEvent{Type: Services.NotificationEventUnreadCount, UnreadCount: unread})
+	ctx.Writer.Flush()
+
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			ctx.SSEvent(event.Type, event)
+			return true
+		case <-heartbeat.C:
+			ctx.SSEvent("ping", time.Now().Unix())
+			return true
+		}
+	})
+}
+
+// streamWebSocket 通过WebSocket推送通知，客户端关闭连接时停止
+func (c *NotificationController) streamWebSocket(ctx *gin.Context, userID uint) {
+	conn, err := c.upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		// Upgrade 失败时已经写入了错误响应
+		return
+	}
+	defer conn.Close()
+
+	streamCtx, cancel := context.WithCancel(ctx.Request.Context())
+	defer cancel()
+	events := c.notificationService.Subscribe(streamCtx, userID)
+
+	// 读取协程只用于感知客户端关闭
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	unread, _ := c.notificationService.UnreadCount(userID)
+	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if err := conn.WriteJSON(Services.NotificationEvent{Type: Services.NotificationEventUnreadCount, UnreadCount: unread}); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(30 * time.Second)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -3,6 +3,7 @@ package Controllers
 import (
 	"cloud-platform-api/app/Models"
 	"cloud-platform-api/app/Services"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -410,7 +411,8 @@ func (c *PerformanceMonitoringController) GetMonitoringStats(ctx *gin.Context) {
 // @Produce json
 // @Param metric body RecordCustomMetricRequest true "自定义指标信息"
 // @Success 201 {object} Response "记录成功"
-// @Failure 400 {object} Response "参数错误"
+// @Failure 400 {object} Response "参数错误或标签不合法"
+// @Failure 422 {object} Response "指标序列数超过上限"
 // @Failure 500 {object} Response "服务器错误"
 // @Router /api/v1/performance/custom-metrics [post]
 func (c *PerformanceMonitoringController) RecordCustomMetric(ctx *gin.Context) {
@@ -432,7 +434,14 @@ func (c *PerformanceMonitoringController) RecordCustomMetric(ctx *gin.Context) {
 	}
 
 	if err := c.monitoringService.RecordCustomMetric(req.MetricType, req.MetricName, req.Value, req.Labels); err != nil {
-		c.Error(ctx, http.StatusInternalServerError, "记录自定义指标失败: "+err.Error())
+		switch {
+		case errors.Is(err, Services.ErrInvalidMetricLabels):
+			c.Error(ctx, http.StatusBadRequest, err.Error())
+		case errors.Is(err, Services.ErrMetricCardinalityExceeded):
+			c.Error(ctx, http.StatusUnprocessableEntity, err.Error())
+		default:
+			c.Error(ctx, http.StatusInternalServerError, "记录自定义指标失败: "+err.Error())
+		}
 		return
 	}
 
@@ -634,6 +643,11 @@ func (c *PerformanceMonitoringController) calculateSystemHealth(metrics map[stri
 		"total":    len(activeAlerts),
 	}
 
+	// 系统指标超过过期时间未更新时，系统资源组件标记为 stale
+	if freshness, ok := metrics["freshness"].(map[string]Services.MetricFreshness); ok && freshness["system"].Stale {
+		health["components"].(map[string]string)["system_resources"] = "stale"
+	}
+
 	return health
 }
 
@@ -81,7 +81,7 @@ func (c *PostController) GetPosts(ctx *gin.Context) {
 			"total": total,
 			"pages": (total + int64(limit) - 1) / int64(limit),
 		},
-	}, "文章列表获取成功")
+	}, "post.list_success")
 }
 
 // GetPost 获取单个文章
@@ -94,13 +94,13 @@ func (c *PostController) GetPost(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		c.ValidationError(ctx, "无效的文章ID")
+		c.ValidationError(ctx, "post.invalid_id")
 		return
 	}
 	
 	var post Models.Post
 	if err := Database.DB.Preload("User").Preload("Category").Preload("Tags").First(&post, id).Error; err != nil {
-		c.NotFound(ctx, "文章不存在")
+		c.NotFound(ctx, "post.not_found")
 		return
 	}
 	
@@ -108,12 +108,12 @@ func (c *PostController) GetPost(ctx *gin.Context) {
 	if post.Status == 0 { // 草稿状态
 		userID, exists := ctx.Get("user_id")
 		if !exists {
-			c.Forbidden(ctx, "无权访问草稿文章")
+			c.Forbidden(ctx, "post.draft_forbidden")
 			return
 		}
 		// 统一使用uint类型进行比较
 		if userIDUint, ok := userID.(uint); !ok || userIDUint != post.UserID {
-			c.Forbidden(ctx, "无权访问草稿文章")
+			c.Forbidden(ctx, "post.draft_forbidden")
 			return
 		}
 	}
@@ -122,7 +122,7 @@ func (c *PostController) GetPost(ctx *gin.Context) {
 	post.IncrementViewCount()
 	Database.DB.Save(&post)
 	
-	c.Success(ctx, post, "文章获取成功")
+	c.Success(ctx, post, "post.show_success")
 }
 
 // CreatePost 创建文章
@@ -142,20 +142,20 @@ func (c *PostController) CreatePost(ctx *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := ctx.Get("user_id")
 	if !exists {
-		c.Unauthorized(ctx, "用户未认证")
+		c.Unauthorized(ctx, "common.unauthenticated")
 		return
 	}
 	
 	userIDUint, ok := userID.(uint)
 	if !ok {
-		c.Unauthorized(ctx, "用户ID格式错误")
+		c.Unauthorized(ctx, "common.invalid_user_id")
 		return
 	}
 	
 	// 验证分类是否存在
 	var category Models.Category
 	if err := Database.DB.First(&category, request.CategoryID).Error; err != nil {
-		c.ValidationError(ctx, "指定的分类不存在")
+		c.ValidationError(ctx, "post.category_not_found")
 		return
 	}
 	
@@ -170,7 +170,7 @@ func (c *PostController) CreatePost(ctx *gin.Context) {
 	}
 	
 	if err := Database.DB.Create(post).Error; err != nil {
-		c.ServerError(ctx, "创建文章失败")
+		c.ServerError(ctx, "post.create_failed")
 		return
 	}
 	
@@ -184,7 +184,7 @@ func (c *PostController) CreatePost(ctx *gin.Context) {
 	// 重新加载关联数据
 	Database.DB.Preload("User").Preload("Category").Preload("Tags").First(post, post.ID)
 	
-	c.Success(ctx, post, "文章创建成功")
+	c.Success(ctx, post, "post.created")
 }
 
 // UpdatePost 更新文章
@@ -197,7 +197,7 @@ func (c *PostController) UpdatePost(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		c.ValidationError(ctx, "无效的文章ID")
+		c.ValidationError(ctx, "post.invalid_id")
 		return
 	}
 	
@@ -210,13 +210,13 @@ func (c *PostController) UpdatePost(ctx *gin.Context) {
 	// 获取当前用户信息
 	userID, exists := ctx.Get("user_id")
 	if !exists {
-		c.Unauthorized(ctx, "用户未认证")
+		c.Unauthorized(ctx, "common.unauthenticated")
 		return
 	}
 	
 	userIDUint, ok := userID.(uint)
 	if !ok {
-		c.Unauthorized(ctx, "用户ID格式错误")
+		c.Unauthorized(ctx, "common.invalid_user_id")
 		return
 	}
 	
@@ -225,13 +225,13 @@ func (c *PostController) UpdatePost(ctx *gin.Context) {
 	// 查找文章
 	var post Models.Post
 	if err := Database.DB.First(&post, id).Error; err != nil {
-		c.NotFound(ctx, "文章不存在")
+		c.NotFound(ctx, "post.not_found")
 		return
 	}
 	
 	// 权限检查：只能更新自己的文章或管理员可以更新所有文章
 	if userRole != "admin" && userIDUint != post.UserID {
-		c.Forbidden(ctx, "只能更新自己的文章")
+		c.Forbidden(ctx, "post.update_forbidden")
 		return
 	}
 	
@@ -249,7 +249,7 @@ func (c *PostController) UpdatePost(ctx *gin.Context) {
 		// 验证分类是否存在
 		var category Models.Category
 		if err := Database.DB.First(&category, request.CategoryID).Error; err != nil {
-			c.ValidationError(ctx, "指定的分类不存在")
+			c.ValidationError(ctx, "post.category_not_found")
 			return
 		}
 		post.CategoryID = request.CategoryID
@@ -260,7 +260,7 @@ func (c *PostController) UpdatePost(ctx *gin.Context) {
 	
 	// 保存更新
 	if err := Database.DB.Save(&post).Error; err != nil {
-		c.ServerError(ctx, "更新文章失败")
+		c.ServerError(ctx, "post.update_failed")
 		return
 	}
 	
@@ -279,7 +279,7 @@ func (c *PostController) UpdatePost(ctx *gin.Context) {
 	// 重新加载关联数据
 	Database.DB.Preload("User").Preload("Category").Preload("Tags").First(&post, post.ID)
 	
-	c.Success(ctx, post, "文章更新成功")
+	c.Success(ctx, post, "post.updated")
 }
 
 // DeletePost 删除文章
@@ -292,20 +292,20 @@ func (c *PostController) DeletePost(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		c.ValidationError(ctx, "无效的文章ID")
+		c.ValidationError(ctx, "post.invalid_id")
 		return
 	}
 	
 	// 获取当前用户信息
 	userID, exists := ctx.Get("user_id")
 	if !exists {
-		c.Unauthorized(ctx, "用户未认证")
+		c.Unauthorized(ctx, "common.unauthenticated")
 		return
 	}
 	
 	userIDUint, ok := userID.(uint)
 	if !ok {
-		c.Unauthorized(ctx, "用户ID格式错误")
+		c.Unauthorized(ctx, "common.invalid_user_id")
 		return
 	}
 	
@@ -314,22 +314,22 @@ func (c *PostController) DeletePost(ctx *gin.Context) {
 	// 查找文章
 	var post Models.Post
 	if err := Database.DB.First(&post, id).Error; err != nil {
-		c.NotFound(ctx, "文章不存在")
+		c.NotFound(ctx, "post.not_found")
 		return
 	}
 	
 	// 权限检查：只能删除自己的文章或管理员可以删除所有文章
 	if userRole != "admin" && userIDUint != post.UserID {
-		c.Forbidden(ctx, "只能删除自己的文章")
+		c.Forbidden(ctx, "post.delete_forbidden")
 		return
 	}
 	
 	// 删除文章（会自动删除关联的标签关系）
 	if err := Database.DB.Delete(&post).Error; err != nil {
-		c.ServerError(ctx, "删除文章失败")
+		c.ServerError(ctx, "post.delete_failed")
 		return
 	}
 	
-	c.Success(ctx, nil, "文章删除成功")
+	c.Success(ctx, nil, "post.deleted")
 }
 
@@ -0,0 +1,202 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/I18n"
+	"cloud-platform-api/app/Services"
+	"errors"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProfilingController 性能剖析控制器
+//
+// 重要功能说明：
+// 1. pprof端点：/debug/pprof 下的标准 net/http/pprof 接口
+// 2. 剖析文件管理：列出、下载、删除阈值突破时自动采集的剖析文件
+// 3. 手动采集：按需采集 goroutine、heap、cpu 等剖析文件并保存到磁盘
+//
+// 安全特性：
+// - 所有接口都需要管理员权限（在路由中配置）
+// - CPU剖析和trace的采集时长不超过 MaxCPUDuration，避免触发请求超时
+type ProfilingController struct {
+	Controller
+	profilingService *Services.ProfilingService
+	logManager       *Services.LogManagerService
+	maxCPUDuration   time.Duration
+}
+
+// CaptureProfileRequest 手动采集剖析文件请求
+type CaptureProfileRequest struct {
+	Type    string `json:"type" binding:"required"`
+	Seconds int    `json:"seconds"`
+}
+
+// NewProfilingController 创建性能剖析控制器
+func NewProfilingController(profilingService *Services.ProfilingService, logManager *Services.LogManagerService) *ProfilingController {
+	return &ProfilingController{
+		profilingService: profilingService,
+		logManager:       logManager,
+		maxCPUDuration:   profilingService.GetConfig().Profiling.MaxCPUDuration,
+	}
+}
+
+// Pprof 标准pprof端点
+// @Summary pprof端点
+// @Description net/http/pprof 标准接口，profile 和 trace 的 seconds 参数不超过配置的最长采集时间（仅管理员）
+// @Tags 性能剖析
+// @Produce octet-stream
+// @Security ApiKeyAuth
+// @Param name path string false "剖析名称" Enums(cmdline,profile,symbol,trace,goroutine,heap,allocs,block,mutex,threadcreate)
+// @Router /debug/pprof/{name} [get]
+func (c *ProfilingController) Pprof(ctx *gin.Context) {
+	switch name := strings.Trim(ctx.Param("name"), "/"); name {
+	case "cmdline":
+		pprof.Cmdline(ctx.Writer, ctx.Request)
+	case "profile":
+		c.limitSeconds(ctx)
+		pprof.Profile(ctx.Writer, ctx.Request)
+	case "symbol":
+		pprof.Symbol(ctx.Writer, ctx.Request)
+	case "trace":
+		c.limitSeconds(ctx)
+		pprof.Trace(ctx.Writer, ctx.Request)
+	default:
+		// Index 根据 /debug/pprof/ 之后的路径分发到具名剖析
+		pprof.Index(ctx.Writer, ctx.Request)
+	}
+}
+
+// limitSeconds 限制 seconds 参数不超过最长采集时间
+func (c *ProfilingController) limitSeconds(ctx *gin.Context) {
+	if c.maxCPUDuration <= 0 {
+		return
+	}
+	limit := int(c.maxCPUDuration / time.Second)
+	query := ctx.Request.URL.Query()
+	seconds, err := strconv.Atoi(query.Get("seconds"))
+	// pprof 默认采集30秒
+	if err != nil || seconds <= 0 {
+		seconds = 30
+	}
+	if seconds > limit {
+		query.Set("seconds", strconv.Itoa(limit))
+		ctx.Request.URL.RawQuery = query.Encode()
+	}
+}
+
+// ListProfiles 剖析文件列表
+// @Summary 剖析文件列表
+// @Description 列出自动和手动采集的剖析文件，按时间倒序（仅管理员）
+// @Tags 性能剖析
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "剖析文件列表"
+// @Router /api/v1/profiles [get]
+func (c *ProfilingController) ListProfiles(ctx *gin.Context) {
+	profiles, err := c.profilingService.List()
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, c.Trans(ctx, "profiles.list_failed", I18n.Params{"error": err.Error()}))
+		return
+	}
+	c.Success(ctx, profiles, "profiles.list_success")
+}
+
+// CaptureProfile 手动采集剖析文件
+// @Summary 手动采集剖析文件
+// @Description 采集剖析文件并保存到磁盘，cpu 类型会阻塞 seconds 秒（仅管理员）
+// @Tags 性能剖析
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body CaptureProfileRequest true "采集参数"
+// @Success 200 {object} Response "剖析文件信息"
+// @Failure 400 {object} Response "不支持的剖析类型"
+// @Failure 409 {object} Response "已有CPU剖析正在进行"
+// @Router /api/v1/profiles [post]
+func (c *ProfilingController) CaptureProfile(ctx *gin.Context) {
+	var req CaptureProfileRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "common.invalid_request")
+		return
+	}
+
+	profileType := strings.ToLower(strings.TrimSpace(req.Type))
+	var info *Services.ProfileInfo
+	var err error
+	if profileType == "cpu" {
+		info, err = c.profilingService.CaptureCPU(ctx.Request.Context(), time.Duration(req.Seconds)*time.Second, Services.ProfileReasonManual)
+	} else {
+		info, err = c.profilingService.Capture(profileType, Services.ProfileReasonManual)
+	}
+	switch {
+	case errors.Is(err, Services.ErrProfileTypeUnsupported):
+		c.Error(ctx, http.StatusBadRequest, c.Trans(ctx, "profiles.unsupported_type", I18n.Params{
+			"type":  req.Type,
+			"types": strings.Join(Services.ProfileTypes, ", "),
+		}))
+		return
+	case errors.Is(err, Services.ErrCPUProfileBusy):
+		c.Error(ctx, http.StatusConflict, "profiles.cpu_busy")
+		return
+	case err != nil:
+		c.Error(ctx, http.StatusInternalServerError, c.Trans(ctx, "profiles.capture_failed", I18n.Params{"error": err.Error()}))
+		return
+	}
+
+	c.logManager.LogAudit(ctx.Request.Context(), "capture_profile", "profile", info.Name, map[string]interface{}{
+		"type":     info.Type,
+		"operator": ctx.GetString("username"),
+	})
+	c.Success(ctx, info, "profiles.captured")
+}
+
+// DownloadProfile 下载剖析文件
+// @Summary 下载剖析文件
+// @Description 下载 pprof 格式的剖析文件，可使用 go tool pprof 分析（仅管理员）
+// @Tags 性能剖析
+// @Produce octet-stream
+// @Security ApiKeyAuth
+// @Param name path string true "文件名"
+// @Success 200 {file} file "剖析文件"
+// @Failure 404 {object} Response "剖析文件不存在"
+// @Router /api/v1/profiles/{name} [get]
+func (c *ProfilingController) DownloadProfile(ctx *gin.Context) {
+	name := ctx.Param("name")
+	path, err := c.profilingService.Path(name)
+	if err != nil {
+		c.Error(ctx, http.StatusNotFound, "profiles.not_found")
+		return
+	}
+	ctx.FileAttachment(path, name)
+}
+
+// DeleteProfile 删除剖析文件
+// @Summary 删除剖析文件
+// @Tags 性能剖析
+// @Produce json
+// @Security ApiKeyAuth
+// @Param name path string true "文件名"
+// @Success 200 {object} Response "删除成功"
+// @Failure 404 {object} Response "剖析文件不存在"
+// @Router /api/v1/profiles/{name} [delete]
+func (c *ProfilingController) DeleteProfile(ctx *gin.Context) {
+	name := ctx.Param("name")
+	if err := c.profilingService.Delete(name); err != nil {
+		if errors.Is(err, Services.ErrProfileNotFound) {
+			c.Error(ctx, http.StatusNotFound, "profiles.not_found")
+			return
+		}
+		c.Error(ctx, http.StatusInternalServerError, c.Trans(ctx, "profiles.delete_failed", I18n.Params{"error": err.Error()}))
+		return
+	}
+
+	c.logManager.LogAudit(ctx.Request.Context(), "delete_profile", "profile", name, map[string]interface{}{
+		"operator": ctx.GetString("username"),
+	})
+	c.Success(ctx, nil, "profiles.deleted")
+}
@@ -2,6 +2,7 @@ package Controllers
 
 import (
 	"cloud-platform-api/app/Services"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -63,6 +64,129 @@ func (c *QueryOptimizationController) GetSlowQueries(ctx *gin.Context) {
 	}, "慢查询列表获取成功")
 }
 
+// GetSlowQueryStats 获取慢查询统计排行
+// @Summary 获取慢查询统计排行
+// @Description 按规范化SQL聚合的慢查询统计，包含调用次数、慢查询次数、平均/最大/P95耗时（毫秒）
+// @Tags 查询优化
+// @Accept json
+// @Produce json
+// @Param sort query string false "排序字段" Enums(p95_time,total_time,max_time,slow_count,call_count,last_seen_at)
+// @Param limit query int false "返回数量，默认为配置的TopN"
+// @Success 200 {object} Response "慢查询统计"
+// @Failure 500 {object} Response "服务器错误"
+// @Router /api/v1/query-optimization/slow-query-stats [get]
+func (c *QueryOptimizationController) GetSlowQueryStats(ctx *gin.Context) {
+	analyzer := c.slowQueryAnalyzer(ctx)
+	if analyzer == nil {
+		return
+	}
+
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "0"))
+	sortBy := ctx.DefaultQuery("sort", "p95_time")
+
+	// 先写入内存中的增量，保证返回最新数据
+	if err := analyzer.Flush(ctx.Request.Context()); err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "写入慢查询统计失败: "+err.Error())
+		return
+	}
+	stats, err := analyzer.TopSlowQueries(ctx.Request.Context(), sortBy, limit)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取慢查询统计失败: "+err.Error())
+		return
+	}
+
+	items := make([]gin.H, 0, len(stats))
+	for i := range stats {
+		items = append(items, gin.H{
+			"id":             stats[i].ID,
+			"fingerprint":    stats[i].Fingerprint,
+			"normalized_sql": stats[i].NormalizedSQL,
+			"table":          stats[i].Table,
+			"call_count":     stats[i].CallCount,
+			"slow_count":     stats[i].SlowCount,
+			"avg_time":       stats[i].AvgTime(),
+			"max_time":       stats[i].MaxTime,
+			"p95_time":       stats[i].P95Time,
+			"total_time":     stats[i].TotalTime,
+			"rows_affected":  stats[i].RowsAffected,
+			"first_seen_at":  stats[i].FirstSeenAt,
+			"last_seen_at":   stats[i].LastSeenAt,
+		})
+	}
+
+	c.Success(ctx, gin.H{
+		"slow_queries": items,
+		"total":        len(items),
+		"sort":         sortBy,
+		"dropped":      analyzer.Dropped(),
+	}, "慢查询统计获取成功")
+}
+
+// ExplainSlowQuery 获取慢查询执行计划
+// @Summary 获取慢查询执行计划
+// @Description 对慢查询最慢一次执行的SQL样本执行EXPLAIN（不带ANALYZE，不会真正执行），仅支持SELECT语句（仅管理员）
+// @Tags 查询优化
+// @Accept json
+// @Produce json
+// @Param id path int true "慢查询统计ID"
+// @Success 200 {object} Response "执行计划"
+// @Failure 400 {object} Response "不支持EXPLAIN"
+// @Failure 404 {object} Response "慢查询统计不存在"
+// @Router /api/v1/query-optimization/slow-query-stats/{id}/explain [get]
+func (c *QueryOptimizationController) ExplainSlowQuery(ctx *gin.Context) {
+	analyzer := c.slowQueryAnalyzer(ctx)
+	if analyzer == nil {
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, "无效的ID参数")
+		return
+	}
+
+	stat, err := analyzer.GetSlowQuery(ctx.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, Services.ErrSlowQueryNotFound) {
+			c.Error(ctx, http.StatusNotFound, err.Error())
+			return
+		}
+		c.Error(ctx, http.StatusInternalServerError, "获取慢查询统计失败: "+err.Error())
+		return
+	}
+
+	plan, err := analyzer.Explain(ctx.Request.Context(), uint(id))
+	switch {
+	case errors.Is(err, Services.ErrExplainUnsupported):
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		c.Error(ctx, http.StatusInternalServerError, "执行EXPLAIN失败: "+err.Error())
+		return
+	}
+
+	c.Success(ctx, gin.H{
+		"id":             stat.ID,
+		"normalized_sql": stat.NormalizedSQL,
+		"sample_sql":     stat.SampleSQL,
+		"plan":           plan,
+	}, "执行计划获取成功")
+}
+
+// slowQueryAnalyzer 获取慢查询分析器，未启用时返回错误响应
+func (c *QueryOptimizationController) slowQueryAnalyzer(ctx *gin.Context) *Services.SlowQueryAnalyzer {
+	if c.queryOptService == nil {
+		c.Error(ctx, http.StatusInternalServerError, "查询优化服务未初始化")
+		return nil
+	}
+	analyzer := c.queryOptService.SlowQueryAnalyzer()
+	if analyzer == nil {
+		c.Error(ctx, http.StatusServiceUnavailable, "慢查询分析未启用")
+		return nil
+	}
+	return analyzer
+}
+
 // GetQueryStatistics 获取查询统计信息
 // @Summary 获取查询统计信息
 // @Description 获取数据库查询的统计数据，包括执行次数、平均耗时等
@@ -0,0 +1,117 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/SBOM"
+	"cloud-platform-api/app/Services"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SBOMController 软件物料清单控制器
+//
+// 功能说明：
+// 1. 返回当前二进制依赖的模块、版本和许可证统计，支持 CycloneDX 格式导出
+// 2. 查看依赖漏洞跟踪记录，手动触发依赖漏洞检查
+//
+// 安全特性：
+// - 所有接口需要管理员权限（在路由中配置），依赖版本信息可被用于定位可利用的漏洞
+type SBOMController struct {
+	Controller
+	dependencyService *Services.DependencyVulnerabilityService
+}
+
+// NewSBOMController 创建软件物料清单控制器，dependencyService 为空时依赖漏洞接口返回503
+func NewSBOMController(dependencyService *Services.DependencyVulnerabilityService) *SBOMController {
+	return &SBOMController{dependencyService: dependencyService}
+}
+
+// SBOMQuery 软件物料清单查询参数
+type SBOMQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=json cyclonedx"` // json（默认）或 cyclonedx
+}
+
+// DependencyVulnerabilityQuery 依赖漏洞查询参数
+type DependencyVulnerabilityQuery struct {
+	IncludeResolved bool `form:"include_resolved"` // 是否包含已解决的漏洞
+}
+
+// GetSBOM 获取软件物料清单
+// @Summary 获取软件物料清单
+// @Tags 安全防护
+// @Produce json
+// @Security ApiKeyAuth
+// @Param format query string false "json（默认）或 cyclonedx"
+// @Success 200 {object} Response "依赖模块、版本和许可证"
+// @Router /api/v1/system/sbom [get]
+func (c *SBOMController) GetSBOM(ctx *gin.Context) {
+	var query SBOMQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	doc, err := SBOM.Load()
+	if err != nil {
+		log.Printf("加载软件物料清单失败: %v", err)
+		c.Error(ctx, http.StatusInternalServerError, "加载软件物料清单失败")
+		return
+	}
+	if query.Format == "cyclonedx" {
+		ctx.JSON(http.StatusOK, doc.CycloneDX())
+		return
+	}
+	c.Success(ctx, doc, "软件物料清单获取成功")
+}
+
+// GetDependencyVulnerabilities 获取依赖漏洞
+// @Summary 获取依赖漏洞
+// @Tags 安全防护
+// @Produce json
+// @Security ApiKeyAuth
+// @Param include_resolved query bool false "是否包含已解决的漏洞"
+// @Success 200 {object} Response "依赖漏洞跟踪记录"
+// @Failure 503 {object} Response "依赖漏洞检查未启用"
+// @Router /api/v1/system/sbom/vulnerabilities [get]
+func (c *SBOMController) GetDependencyVulnerabilities(ctx *gin.Context) {
+	if c.dependencyService == nil {
+		c.Error(ctx, http.StatusServiceUnavailable, "依赖漏洞检查未启用")
+		return
+	}
+	var query DependencyVulnerabilityQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	records, err := c.dependencyService.List(query.IncludeResolved)
+	if err != nil {
+		log.Printf("获取依赖漏洞失败: %v", err)
+		c.Error(ctx, http.StatusInternalServerError, "获取依赖漏洞失败")
+		return
+	}
+	c.Success(ctx, records, "依赖漏洞获取成功")
+}
+
+// CheckDependencyVulnerabilities 检查依赖漏洞
+// @Summary 立即检查依赖漏洞
+// @Description 用依赖清单查询OSV漏洞库，新发现的漏洞记录为 dependency_vulnerability 安全事件
+// @Tags 安全防护
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "检查结果"
+// @Failure 502 {object} Response "查询漏洞库失败"
+// @Failure 503 {object} Response "依赖漏洞检查未启用"
+// @Router /api/v1/system/sbom/vulnerabilities/check [post]
+func (c *SBOMController) CheckDependencyVulnerabilities(ctx *gin.Context) {
+	if c.dependencyService == nil {
+		c.Error(ctx, http.StatusServiceUnavailable, "依赖漏洞检查未启用")
+		return
+	}
+	result, err := c.dependencyService.Check(ctx.Request.Context())
+	if err != nil {
+		log.Printf("检查依赖漏洞失败: %v", err)
+		c.Error(ctx, http.StatusBadGateway, "检查依赖漏洞失败: "+err.Error())
+		return
+	}
+	c.Success(ctx, result, "依赖漏洞检查完成")
+}
@@ -0,0 +1,282 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SMSController 短信模板、投递记录和短信MFA验证控制器
+type SMSController struct {
+	Controller
+	smsService            *Services.SMSService
+	tokenBlacklistService *Services.TokenBlacklistService
+	webhookSecret         string
+}
+
+// NewSMSController 创建短信控制器
+//
+// webhookSecret 为空时送达回执接口不可用，避免任何人都能修改投递状态。
+func NewSMSController(smsService *Services.SMSService, webhookSecret string) *SMSController {
+	return &SMSController{
+		smsService:    smsService,
+		webhookSecret: webhookSecret,
+	}
+}
+
+// SetTokenBlacklistService 设置黑名单服务，短信MFA验证通过后清除重新验证标记
+func (c *SMSController) SetTokenBlacklistService(service *Services.TokenBlacklistService) {
+	c.tokenBlacklistService = service
+}
+
+// GetTemplates 获取短信模板
+// @Summary 获取短信模板
+// @Description 获取全部短信模板，包含未修改过的内置模板（仅管理员）
+// @Tags 短信
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "模板列表"
+// @Router /api/v1/sms/templates [get]
+func (c *SMSController) GetTemplates(ctx *gin.Context) {
+	templates, err := c.smsService.ListTemplates()
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取短信模板失败: "+err.Error())
+		return
+	}
+	c.Success(ctx, templates, "短信模板获取成功")
+}
+
+// SaveTemplateRequest 保存短信模板请求
+type SaveTemplateRequest struct {
+	Name         string `json:"name" binding:"required,max=100"`
+	Content      string `json:"content" binding:"required"`
+	ProviderCode string `json:"provider_code" binding:"max=64"`
+	Description  string `json:"description" binding:"max=255"`
+	Enabled      *bool  `json:"enabled"`
+}
+
+// SaveTemplate 创建或更新短信模板
+// @Summary 保存短信模板
+// @Description 按名称创建或更新短信模板，内容中使用 {{name}} 引用变量；阿里云需要填写审核通过的模板CODE（仅管理员）
+// @Tags 短信
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body SaveTemplateRequest true "模板"
+// @Success 200 {object} Response "保存后的模板"
+// @Router /api/v1/sms/templates [post]
+func (c *SMSController) SaveTemplate(ctx *gin.Context) {
+	var req SaveTemplateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	template := &Models.SMSTemplate{
+		Name:         req.Name,
+		Content:      req.Content,
+		ProviderCode: req.ProviderCode,
+		Description:  req.Description,
+		Enabled:      req.Enabled == nil || *req.Enabled,
+	}
+	if err := c.smsService.SaveTemplate(template); err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.Success(ctx, template, "短信模板已保存")
+}
+
+// DeleteTemplate 删除短信模板
+// @Summary 删除短信模板
+// @Description 删除短信模板，内置模板删除后恢复默认内容（仅管理员）
+// @Tags 短信
+// @Produce json
+// @Security ApiKeyAuth
+// @Param name path string true "模板名称"
+// @Success 200 {object} Response "删除成功"
+// @Failure 404 {object} Response "模板不存在"
+// @Router /api/v1/sms/templates/{name} [delete]
+func (c *SMSController) DeleteTemplate(ctx *gin.Context) {
+	err := c.smsService.DeleteTemplate(ctx.Param("name"))
+	switch {
+	case errors.Is(err, Services.ErrSMSTemplateNotFound):
+		c.Error(ctx, http.StatusNotFound, err.Error())
+	case err != nil:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	default:
+		c.Success(ctx, nil, "短信模板已删除")
+	}
+}
+
+// GetMessages 获取短信投递记录
+// @Summary 获取短信投递记录
+// @Description 按投递状态和号码筛选短信投递记录（仅管理员）
+// @Tags 短信
+// @Produce json
+// @Security ApiKeyAuth
+// @Param status query string false "投递状态(pending/sent/delivered/undelivered/failed)"
+// @Param phone query string false "号码"
+// @Param page query int false "页码"
+// @Param limit query int false "每页数量"
+// @Success 200 {object} Response "投递记录列表"
+// @Router /api/v1/sms/messages [get]
+func (c *SMSController) GetMessages(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	messages, total, err := c.smsService.ListMessages(Services.SMSMessageFilter{
+		Status: ctx.Query("status"),
+		Phone:  ctx.Query("phone"),
+		Page:   page,
+		Limit:  limit,
+	})
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取短信投递记录失败: "+err.Error())
+		return
+	}
+
+	c.Success(ctx, gin.H{
+		"messages":    messages,
+		"total":       total,
+		"page":        page,
+		"limit":       limit,
+		"total_pages": (int(total) + limit - 1) / limit,
+	}, "短信投递记录获取成功")
+}
+
+// HandleCallback 处理服务商送达回执
+// @Summary 短信送达回执
+// @Description 接收阿里云短信状态报告（JSON）和Twilio StatusCallback（表单），通过 token 参数校验
+// @Tags 短信
+// @Accept json
+// @Produce json
+// @Param provider path string true "服务商(aliyun/twilio)"
+// @Param token query string true "回调校验令牌"
+// @Success 200 {object} Response "处理结果"
+// @Failure 401 {object} Response "校验失败"
+// @Router /api/v1/sms/callbacks/{provider} [post]
+func (c *SMSController) HandleCallback(ctx *gin.Context) {
+	token := ctx.Query("token")
+	if c.webhookSecret == "" || subtle.ConstantTimeCompare([]byte(token), []byte(c.webhookSecret)) != 1 {
+		c.Error(ctx, http.StatusUnauthorized, "回调校验失败")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, 5<<20))
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, "读取回调内容失败")
+		return
+	}
+
+	updated, err := c.smsService.HandleDeliveryReceipt(ctx.Param("provider"), body)
+	switch {
+	case errors.Is(err, Services.ErrUnsupportedSMSProvider):
+		c.Error(ctx, http.StatusNotFound, err.Error())
+	case err != nil:
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+	default:
+		// 阿里云要求返回 code=0 表示接收成功
+		ctx.JSON(http.StatusOK, gin.H{
+			"code":    0,
+			"msg":     "成功",
+			"message": fmt.Sprintf("已更新 %d 条投递记录", updated),
+			"data":    gin.H{"updated": updated},
+		})
+	}
+}
+
+// SendMFACode 发送短信MFA验证码
+// @Summary 发送短信MFA验证码
+// @Description 向当前用户绑定的手机号发送验证码，用于MFA重新验证
+// @Tags 短信
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "发送成功，返回脱敏号码"
+// @Failure 400 {object} Response "未绑定手机号"
+// @Failure 429 {object} Response "发送过于频繁"
+// @Router /api/v1/auth/mfa/sms/send [post]
+func (c *SMSController) SendMFACode(ctx *gin.Context) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "需要登录")
+		return
+	}
+
+	phone, err := c.smsService.SendMFACode(ctx.Request.Context(), userID)
+	switch {
+	case errors.Is(err, Services.ErrSMSCodeTooFrequent):
+		interval := c.smsService.GetConfig().CodeInterval
+		c.TooManyRequests(ctx, err.Error(), int(interval/time.Second))
+	case errors.Is(err, Services.ErrSMSPhoneNotBound), errors.Is(err, Services.ErrInvalidPhoneNumber):
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.Error(ctx, http.StatusNotFound, "用户不存在")
+	case err != nil:
+		c.Error(ctx, http.StatusBadGateway, "验证码发送失败: "+err.Error())
+	default:
+		c.Success(ctx, gin.H{"phone": phone, "expires_in": int(c.smsService.GetConfig().CodeTTL / time.Second)}, "验证码已发送")
+	}
+}
+
+// VerifyMFACodeRequest 校验短信MFA验证码请求
+type VerifyMFACodeRequest struct {
+	Code string `json:"code" binding:"required,max=10"`
+}
+
+// VerifyMFACode 校验短信MFA验证码
+// @Summary 校验短信MFA验证码
+// @Description 校验通过后清除当前用户的MFA重新验证标记
+// @Tags 短信
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body VerifyMFACodeRequest true "验证码"
+// @Success 200 {object} Response "验证成功"
+// @Failure 400 {object} Response "验证码错误或已过期"
+// @Router /api/v1/auth/mfa/sms/verify [post]
+func (c *SMSController) VerifyMFACode(ctx *gin.Context) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "需要登录")
+		return
+	}
+	var req VerifyMFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	err = c.smsService.VerifyMFACode(userID, req.Code)
+	switch {
+	case errors.Is(err, Services.ErrSMSCodeInvalid), errors.Is(err, Services.ErrSMSCodeTooManyAttempts),
+		errors.Is(err, Services.ErrSMSPhoneNotBound), errors.Is(err, Services.ErrInvalidPhoneNumber):
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.Error(ctx, http.StatusNotFound, "用户不存在")
+	case err != nil:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	default:
+		if c.tokenBlacklistService != nil {
+			if err := c.tokenBlacklistService.ClearMFAReverification(userID); err != nil {
+				c.Error(ctx, http.StatusInternalServerError, "清除MFA验证标记失败: "+err.Error())
+				return
+			}
+		}
+		c.Success(ctx, gin.H{"verified": true}, "MFA验证成功")
+	}
+}
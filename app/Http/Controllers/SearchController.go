@@ -0,0 +1,227 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/I18n"
+	"cloud-platform-api/app/Services"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchController 全文搜索控制器
+type SearchController struct {
+	Controller
+	searchService *Services.SearchService
+}
+
+// NewSearchController 创建全文搜索控制器
+func NewSearchController() *SearchController {
+	return &SearchController{
+		searchService: Services.NewSearchService(nil),
+	}
+}
+
+// SetSearchService 设置搜索服务
+func (c *SearchController) SetSearchService(service *Services.SearchService) {
+	c.searchService = service
+}
+
+// Search 全文搜索
+// @Summary 全文搜索
+// @Description 搜索用户、文章、审计日志和安全事件，支持分页、高亮和过滤
+// @Tags 搜索
+// @Accept json
+// @Produce json
+// @Param q query string true "搜索关键词"
+// @Param types query string false "搜索的索引，逗号分隔" Enums(users,posts,audit_logs,security_events)
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Param highlight query bool false "是否返回高亮片段" default(true)
+// @Param filter[status] query string false "按字段精确过滤，如 filter[status]=1"
+// @Param from query string false "开始时间（RFC3339或YYYY-MM-DD）"
+// @Param to query string false "结束时间（RFC3339或YYYY-MM-DD）"
+// @Success 200 {object} Response "搜索结果"
+// @Failure 400 {object} Response "请求参数错误"
+// @Failure 403 {object} Response "无权搜索该索引"
+// @Router /api/v1/search [get]
+//
+// 权限说明：
+// - 管理员可以搜索全部索引
+// - 普通用户只能搜索文章，且只返回已发布的文章
+func (c *SearchController) Search(ctx *gin.Context) {
+	q := strings.TrimSpace(ctx.Query("q"))
+	if q == "" {
+		c.Error(ctx, http.StatusBadRequest, "search.query_required")
+		return
+	}
+	if len([]rune(q)) > 200 {
+		c.Error(ctx, http.StatusBadRequest, "search.query_too_long")
+		return
+	}
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("page_size", "0"))
+
+	query := Services.SearchQuery{
+		Query:     q,
+		Filters:   ctx.QueryMap("filter"),
+		Page:      page,
+		PageSize:  pageSize,
+		Highlight: ctx.DefaultQuery("highlight", "true") != "false",
+	}
+	if types := ctx.Query("types"); types != "" {
+		for _, name := range strings.Split(types, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				query.Indices = append(query.Indices, name)
+			}
+		}
+	}
+
+	var err error
+	if query.From, err = parseSearchTime(ctx.Query("from"), false); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "search.invalid_time")
+		return
+	}
+	if query.To, err = parseSearchTime(ctx.Query("to"), true); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "search.invalid_time")
+		return
+	}
+
+	allowed := c.allowedIndices(ctx)
+	if !c.IsAdmin(ctx) {
+		// 普通用户只能看到已发布的文章
+		if query.Filters == nil {
+			query.Filters = map[string]string{}
+		}
+		query.Filters["status"] = "1"
+	}
+
+	result, err := c.searchService.Search(ctx.Request.Context(), query, allowed)
+	if err != nil {
+		var i18nErr *I18n.Error
+		if errors.As(err, &i18nErr) {
+			status := http.StatusBadRequest
+			if i18nErr.Key == "search.index_forbidden" {
+				status = http.StatusForbidden
+			}
+			c.Error(ctx, status, c.TransError(ctx, err))
+			return
+		}
+		c.Error(ctx, http.StatusInternalServerError, c.Trans(ctx, "search.failed", I18n.Params{"error": err.Error()}))
+		return
+	}
+
+	c.Success(ctx, result, "search.success")
+}
+
+// GetIndices 获取索引状态
+// @Summary 获取搜索索引状态
+// @Description 获取每个搜索索引的驱动、文档数和最后同步时间（仅管理员）
+// @Tags 搜索
+// @Produce json
+// @Success 200 {object} Response "索引状态"
+// @Router /api/v1/search/indices [get]
+func (c *SearchController) GetIndices(ctx *gin.Context) {
+	statuses, err := c.searchService.IndexStatus(ctx.Request.Context())
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, c.Trans(ctx, "search.failed", I18n.Params{"error": err.Error()}))
+		return
+	}
+
+	c.Success(ctx, gin.H{
+		"driver":  c.searchService.Driver().Name(),
+		"indices": statuses,
+	}, "search.indices_success")
+}
+
+// Reindex 重建索引
+// @Summary 重建搜索索引
+// @Description 从数据库全量重建指定索引，搜索引擎驱动下重建完成后原子切换别名（仅管理员）
+// @Tags 搜索
+// @Produce json
+// @Param index path string true "索引名称"
+// @Success 200 {object} Response "重建结果"
+// @Router /api/v1/search/indices/{index}/reindex [post]
+func (c *SearchController) Reindex(ctx *gin.Context) {
+	name := ctx.Param("index")
+	if _, ok := Services.LookupSearchIndex(name); !ok {
+		c.Error(ctx, http.StatusNotFound, c.Trans(ctx, "search.unknown_index", I18n.Params{"index": name}))
+		return
+	}
+
+	start := time.Now()
+	count, err := c.searchService.Reindex(ctx.Request.Context(), name)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, c.Trans(ctx, "search.reindex_failed", I18n.Params{"error": c.TransError(ctx, err)}))
+		return
+	}
+
+	c.Success(ctx, gin.H{
+		"index":     name,
+		"documents": count,
+		"duration":  time.Since(start).String(),
+	}, "search.reindex_success")
+}
+
+// DropIndex 删除索引
+// @Summary 删除搜索索引
+// @Description 删除搜索引擎中的指定索引，数据库驱动不支持此操作（仅管理员）
+// @Tags 搜索
+// @Produce json
+// @Param index path string true "索引名称"
+// @Success 200 {object} Response "删除成功"
+// @Router /api/v1/search/indices/{index} [delete]
+func (c *SearchController) DropIndex(ctx *gin.Context) {
+	name := ctx.Param("index")
+	if _, ok := Services.LookupSearchIndex(name); !ok {
+		c.Error(ctx, http.StatusNotFound, c.Trans(ctx, "search.unknown_index", I18n.Params{"index": name}))
+		return
+	}
+	if c.searchService.Driver().Name() == "database" {
+		c.Error(ctx, http.StatusBadRequest, "search.drop_unsupported")
+		return
+	}
+
+	if err := c.searchService.DropIndex(ctx.Request.Context(), name); err != nil {
+		c.Error(ctx, http.StatusInternalServerError, c.Trans(ctx, "search.failed", I18n.Params{"error": err.Error()}))
+		return
+	}
+
+	c.Success(ctx, gin.H{"index": name}, "search.drop_success")
+}
+
+// allowedIndices 获取当前用户可以搜索的索引
+func (c *SearchController) allowedIndices(ctx *gin.Context) []string {
+	admin := c.IsAdmin(ctx)
+	var allowed []string
+	for _, index := range Services.SearchIndices() {
+		if admin || !index.AdminOnly {
+			allowed = append(allowed, index.Name)
+		}
+	}
+	return allowed
+}
+
+// parseSearchTime 解析时间参数（RFC3339或YYYY-MM-DD）
+//
+// 只有日期的结束时间取当天最后一刻。
+func parseSearchTime(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &t, nil
+}
@@ -0,0 +1,154 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Services"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SecurityArchiveFileQuery 归档文件查询参数
+type SecurityArchiveFileQuery struct {
+	Table     string `form:"table"`      // 表名：audit_logs、security_events、login_attempts，为空时返回全部表
+	StartTime string `form:"start_time"` // 开始时间（RFC3339）
+	EndTime   string `form:"end_time"`   // 结束时间（RFC3339）
+}
+
+// SecurityArchiveSearchQuery 归档搜索参数
+type SecurityArchiveSearchQuery struct {
+	SecurityArchiveFileQuery
+	Q      string            `form:"q"`      // 关键字，不区分大小写
+	Filter map[string]string `form:"filter"` // 字段筛选 filter[field]=value，字段名为记录的JSON字段名
+	Limit  int               `form:"limit"`  // 最多返回的记录数，默认100，最多1000
+}
+
+// SecurityArchiveController 安全数据归档控制器
+// 功能说明：
+// 1. 查看归档索引中的文件（表、时间范围、记录数和校验值）
+// 2. 按表、时间范围、字段和关键字搜索归档文件中的记录，用于合规调查，每次搜索记录审计日志
+type SecurityArchiveController struct {
+	Controller
+	archiveService *Services.SecurityArchiveService
+}
+
+// NewSecurityArchiveController 创建安全数据归档控制器
+func NewSecurityArchiveController(archiveService *Services.SecurityArchiveService) *SecurityArchiveController {
+	return &SecurityArchiveController{archiveService: archiveService}
+}
+
+// GetArchiveFiles 获取归档文件列表
+// @Summary 获取归档文件列表
+// @Description 返回归档索引中与时间范围重叠的文件，按记录时间倒序（仅管理员）
+// @Tags 安全防护
+// @Produce json
+// @Security ApiKeyAuth
+// @Param table query string false "表名"
+// @Param start_time query string false "开始时间（RFC3339）"
+// @Param end_time query string false "结束时间（RFC3339）"
+// @Success 200 {object} Response "归档文件列表"
+// @Failure 404 {object} Response "表不支持归档"
+// @Router /api/v1/security/archive/files [get]
+func (c *SecurityArchiveController) GetArchiveFiles(ctx *gin.Context) {
+	from, to, ok := c.timeRange(ctx)
+	if !ok {
+		return
+	}
+	files, err := c.archiveService.Files(ctx.Request.Context(), ctx.Query("table"), from, to)
+	if err != nil {
+		c.archiveError(ctx, err)
+		return
+	}
+	c.Success(ctx, gin.H{
+		"tables": c.archiveService.Tables(),
+		"files":  files,
+	}, "获取归档文件成功")
+}
+
+// SearchArchive 搜索归档记录
+// @Summary 搜索归档记录
+// @Description 按表、时间范围、字段和关键字搜索归档文件中的记录，读取前校验文件的SHA-256（仅管理员）
+// @Tags 安全防护
+// @Produce json
+// @Security ApiKeyAuth
+// @Param table query string false "表名"
+// @Param start_time query string false "开始时间（RFC3339）"
+// @Param end_time query string false "结束时间（RFC3339）"
+// @Param q query string false "关键字"
+// @Param limit query int false "最多返回的记录数"
+// @Success 200 {object} Response "搜索结果"
+// @Failure 404 {object} Response "表不支持归档"
+// @Failure 409 {object} Response "归档文件校验失败"
+// @Router /api/v1/security/archive/search [get]
+func (c *SecurityArchiveController) SearchArchive(ctx *gin.Context) {
+	from, to, ok := c.timeRange(ctx)
+	if !ok {
+		return
+	}
+	query := Services.SecurityArchiveQuery{
+		Table:   ctx.Query("table"),
+		From:    from,
+		To:      to,
+		Filters: ctx.QueryMap("filter"),
+		Text:    ctx.Query("q"),
+	}
+	if value := ctx.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			c.Error(ctx, http.StatusBadRequest, "limit 必须为正整数")
+			return
+		}
+		query.Limit = limit
+	}
+
+	result, err := c.archiveService.Search(ctx.Request.Context(), query)
+	if err != nil {
+		c.archiveError(ctx, err)
+		return
+	}
+	c.audit(ctx, fmt.Sprintf("搜索安全数据归档 table=%q q=%q filter=%v 返回 %d 条记录", query.Table, query.Text, query.Filters, len(result.Records)))
+	c.Success(ctx, result, "搜索归档记录成功")
+}
+
+// timeRange 解析 start_time 和 end_time，格式无效时返回400
+func (c *SecurityArchiveController) timeRange(ctx *gin.Context) (time.Time, time.Time, bool) {
+	var from, to time.Time
+	for param, target := range map[string]*time.Time{"start_time": &from, "end_time": &to} {
+		value := ctx.Query(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.Error(ctx, http.StatusBadRequest, "时间格式无效: "+param)
+			return from, to, false
+		}
+		*target = parsed
+	}
+	return from, to, true
+}
+
+// audit 记录归档搜索的审计日志，记录失败不影响搜索结果
+func (c *SecurityArchiveController) audit(ctx *gin.Context, description string) {
+	if Database.DB == nil {
+		return
+	}
+	userID, _ := c.GetCurrentUser(ctx)
+	Services.NewAuditService(Database.DB).LogUserAction(nil, userID, ctx.GetString("username"), "security_archive_search", "security_archive", 0, description)
+}
+
+// archiveError 安全数据归档错误响应
+func (c *SecurityArchiveController) archiveError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, Services.ErrSecurityArchiveTableUnknown):
+		c.Error(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, Services.ErrSecurityArchiveChecksum):
+		c.Error(ctx, http.StatusConflict, err.Error())
+	default:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -1,18 +1,29 @@
 package Controllers
 
 import (
+	"bytes"
 	"cloud-platform-api/app/Models"
 	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // SecurityController 安全防护控制器
 type SecurityController struct {
 	Controller
 	securityService *Services.SecurityService
+	sharingService  *Services.ThreatIntelSharingService
+	archiveService  *Services.TableArchiveService
 }
 
 // NewSecurityController 创建安全防护控制器
@@ -25,39 +36,160 @@ func (c *SecurityController) SetSecurityService(service *Services.SecurityServic
 	c.securityService = service
 }
 
+// SetTableArchiveService 设置大表归档服务，设置后安全事件和登录尝试的列表查询合并在线数据和归档数据
+func (c *SecurityController) SetTableArchiveService(service *Services.TableArchiveService) {
+	c.archiveService = service
+}
+
+// errArchiveCursorUnsupported 归档查询合并多个数据源，只支持页码分页
+var errArchiveCursorUnsupported = errors.New("启用归档后不支持游标分页，请使用page参数")
+
+// errArchiveFilterUnsupported 归档查询只支持等值筛选、时间字段范围和按时间字段排序
+var errArchiveFilterUnsupported = errors.New("启用归档后只支持等值筛选、时间字段（created_at或attempt_time）的gte/lt范围和按时间字段排序")
+
+// securityAlertQuerySpec 安全告警列表可筛选、排序和返回的字段
+var securityAlertQuerySpec = Utils.QuerySpec{
+	Fields: map[string]Utils.QueryField{
+		"id":           {Column: "id", Type: Utils.QueryInt, Filter: true, Sort: true},
+		"alert_type":   {Column: "alert_type", Type: Utils.QueryString, Filter: true},
+		"severity":     {Column: "severity", Type: Utils.QueryString, Filter: true, Sort: true},
+		"title":        {Column: "title", Type: Utils.QueryString, Filter: true},
+		"description":  {Column: "description", Type: Utils.QueryString},
+		"source":       {Column: "source", Type: Utils.QueryString, Filter: true},
+		"user_id":      {Column: "user_id", Type: Utils.QueryInt, Filter: true},
+		"ip_address":   {Column: "ip_address", Type: Utils.QueryString, Filter: true},
+		"resource":     {Column: "resource", Type: Utils.QueryString, Filter: true},
+		"risk_score":   {Column: "risk_score", Type: Utils.QueryFloat, Filter: true, Sort: true},
+		"status":       {Column: "status", Type: Utils.QueryString, Filter: true},
+		"acknowledged": {Column: "acknowledged", Type: Utils.QueryBool, Filter: true},
+		"resolved":     {Column: "resolved", Type: Utils.QueryBool, Filter: true},
+		"resolved_at":  {Column: "resolved_at", Type: Utils.QueryTime, Filter: true},
+		"created_at":   {Column: "created_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+	},
+	DefaultSort: "-created_at",
+}
+
+// archivedList 通过归档服务分页查询，start_time、end_time 为RFC3339格式的可选时间范围
+// q 不为空时将等值筛选、时间字段 timeColumn 的范围和排序转换为归档查询条件
+// 返回 false 表示未启用归档或表未分区，调用方使用原有查询
+func (c *SecurityController) archivedList(ctx *gin.Context, table, timeColumn string, req Utils.PageRequest, q *Utils.ListQuery, dest interface{}) (Utils.PageMeta, bool, error) {
+	if c.archiveService == nil || !c.archiveService.Partitioned(table) {
+		return Utils.PageMeta{}, false, nil
+	}
+	if req.Mode == Utils.PageModeCursor {
+		return Utils.PageMeta{}, true, errArchiveCursorUnsupported
+	}
+
+	query := Services.ArchiveQuery{Offset: (req.Page - 1) * req.Limit, Limit: req.Limit}
+	if start := ctx.Query("start_time"); start != "" {
+		if t, err := time.Parse(time.RFC3339, start); err == nil {
+			query.From = t
+		}
+	}
+	if end := ctx.Query("end_time"); end != "" {
+		if t, err := time.Parse(time.RFC3339, end); err == nil {
+			query.To = t
+		}
+	}
+	if q != nil {
+		if err := applyArchiveListQuery(&query, q, timeColumn); err != nil {
+			return Utils.PageMeta{}, true, err
+		}
+	}
+
+	total, err := c.archiveService.Query(ctx.Request.Context(), table, query, dest)
+	return Utils.OffsetPageMeta(req.Page, req.Limit, total), true, err
+}
+
+// applyArchiveListQuery 将列表查询转换为归档查询条件
+func applyArchiveListQuery(query *Services.ArchiveQuery, q *Utils.ListQuery, timeColumn string) error {
+	for _, filter := range q.Filters {
+		switch {
+		case filter.Op == Utils.FilterEq:
+			if query.Where == nil {
+				query.Where = make(map[string]interface{})
+			}
+			query.Where[filter.Field] = filter.Value
+		case filter.Field == timeColumn && filter.Op == Utils.FilterGte:
+			query.From = filter.Value.(time.Time)
+		case filter.Field == timeColumn && filter.Op == Utils.FilterLt:
+			query.To = filter.Value.(time.Time)
+		default:
+			return errArchiveFilterUnsupported
+		}
+	}
+	switch {
+	case len(q.Sorts) == 0:
+	case len(q.Sorts) == 1 && q.Sorts[0].Field == timeColumn:
+		query.Ascending = !q.Sorts[0].Desc
+	default:
+		return errArchiveFilterUnsupported
+	}
+	return nil
+}
+
+// pageRequest 解析分页参数，游标无效时返回400
+func (c *SecurityController) pageRequest(ctx *gin.Context) (Utils.PageRequest, bool) {
+	req, err := c.ParsePageRequest(ctx)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return req, false
+	}
+	return req, true
+}
+
+// listQuery 解析分页参数和筛选、排序、字段参数，参数无效时返回400
+func (c *SecurityController) listQuery(ctx *gin.Context, spec Utils.QuerySpec) (Utils.PageRequest, *Utils.ListQuery, bool) {
+	req, ok := c.pageRequest(ctx)
+	if !ok {
+		return req, nil, false
+	}
+	q, err := Utils.ParseListQuery(ctx.Request.URL.Query(), spec)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return req, nil, false
+	}
+	return req, q, true
+}
+
+// listResult 输出统一的列表响应，参数错误返回400，查询失败返回500；q 不为空时只返回指定字段
+func (c *SecurityController) listResult(ctx *gin.Context, table string, q *Utils.ListQuery, data interface{}, meta Utils.PageMeta, err error, message string) {
+	if err == nil && q != nil {
+		data, err = q.Project(data)
+	}
+	switch {
+	case errors.Is(err, Utils.ErrInvalidCursor), errors.Is(err, Utils.ErrInvalidQuery),
+		errors.Is(err, errArchiveCursorUnsupported), errors.Is(err, errArchiveFilterUnsupported):
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+	case err != nil:
+		log.Printf("分页查询失败: table=%s, error=%v", table, err)
+		c.Error(ctx, http.StatusInternalServerError, "查询失败")
+	default:
+		c.ListSuccess(ctx, data, meta, message)
+	}
+}
+
 // GetSecurityEvents 获取安全事件列表
 func (c *SecurityController) GetSecurityEvents(ctx *gin.Context) {
 	if c.securityService == nil {
 		c.Error(ctx, http.StatusInternalServerError, "安全防护服务未初始化")
 		return
 	}
+	req, q, ok := c.listQuery(ctx, Services.SecurityEventQuerySpec)
+	if !ok {
+		return
+	}
 
-	// 获取查询参数
-	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
-
-	// 构建查询条件
-	query := c.securityService.GetDB().Model(&Models.SecurityEvent{})
-
-	// 获取总数
-	var total int64
-	query.Count(&total)
-
-	// 分页查询
+	// 启用归档时按时间范围合并在线数据和归档数据
 	var events []Models.SecurityEvent
-	offset := (page - 1) * limit
-	query.Order("created_at DESC").
-		Offset(offset).
-		Limit(limit).
-		Find(&events)
-
-	c.Success(ctx, gin.H{
-		"events":     events,
-		"total":      total,
-		"page":       page,
-		"limit":      limit,
-		"total_pages": (int(total) + limit - 1) / limit,
-	}, "安全事件列表获取成功")
+	meta, archived, err := c.archivedList(ctx, "security_events", "created_at", req, q, &events)
+	if !archived {
+		query, order, applyErr := q.Apply(c.securityService.GetDB().WithContext(ctx.Request.Context()).Model(&Models.SecurityEvent{}), req)
+		if err = applyErr; err == nil {
+			meta, err = Utils.Paginate(query, req, order, &events)
+		}
+	}
+	c.listResult(ctx, "security_events", q, events, meta, err, "安全事件列表获取成功")
 }
 
 // GetThreatIntelligence 获取威胁情报列表
@@ -66,33 +198,15 @@ func (c *SecurityController) GetThreatIntelligence(ctx *gin.Context) {
 		c.Error(ctx, http.StatusInternalServerError, "安全防护服务未初始化")
 		return
 	}
+	req, ok := c.pageRequest(ctx)
+	if !ok {
+		return
+	}
 
-	// 获取查询参数
-	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
-
-	// 构建查询条件
-	query := c.securityService.GetDB().Model(&Models.ThreatIntelligence{})
-
-	// 获取总数
-	var total int64
-	query.Count(&total)
-
-	// 分页查询
 	var threats []Models.ThreatIntelligence
-	offset := (page - 1) * limit
-	query.Order("created_at DESC").
-		Offset(offset).
-		Limit(limit).
-		Find(&threats)
-
-	c.Success(ctx, gin.H{
-		"threats":    threats,
-		"total":      total,
-		"page":       page,
-		"limit":      limit,
-		"total_pages": (int(total) + limit - 1) / limit,
-	}, "威胁情报列表获取成功")
+	query := c.securityService.GetDB().WithContext(ctx.Request.Context()).Model(&Models.ThreatIntelligence{})
+	meta, err := Utils.Paginate(query, req, Utils.PageOrder{Column: "created_at"}, &threats)
+	c.listResult(ctx, "threat_intelligence", nil, threats, meta, err, "威胁情报列表获取成功")
 }
 
 // GetLoginAttempts 获取登录尝试记录
@@ -101,68 +215,232 @@ func (c *SecurityController) GetLoginAttempts(ctx *gin.Context) {
 		c.Error(ctx, http.StatusInternalServerError, "安全防护服务未初始化")
 		return
 	}
+	req, q, ok := c.listQuery(ctx, Services.LoginAttemptQuerySpec)
+	if !ok {
+		return
+	}
 
-	// 获取查询参数
-	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	// 启用归档时按时间范围合并在线数据和归档数据
+	var attempts []Models.LoginAttempt
+	meta, archived, err := c.archivedList(ctx, "login_attempts", "attempt_time", req, q, &attempts)
+	if !archived {
+		query, order, applyErr := q.Apply(c.securityService.GetDB().WithContext(ctx.Request.Context()).Model(&Models.LoginAttempt{}), req)
+		if err = applyErr; err == nil {
+			meta, err = Utils.Paginate(query, req, order, &attempts)
+		}
+	}
+	c.listResult(ctx, "login_attempts", q, attempts, meta, err, "登录尝试记录获取成功")
+}
 
-	// 构建查询条件
-	query := c.securityService.GetDB().Model(&Models.LoginAttempt{})
+// GetAccountLockouts 获取账户锁定记录
+// @Summary 获取账户锁定记录
+// @Description 分页查询账户锁定记录，可按用户名、IP、锁定类型筛选或只查询生效中的锁定（仅管理员）
+// @Tags 安全防护
+// @Produce json
+// @Security ApiKeyAuth
+// @Param username query string false "用户名（模糊匹配）"
+// @Param ip_address query string false "IP地址"
+// @Param lockout_type query string false "锁定类型"
+// @Param active query bool false "只查询生效中的锁定"
+// @Param page query int false "页码" default(1)
+// @Param limit query int false "每页数量" default(20)
+// @Param cursor query string false "上一页返回的 meta.cursor，传入时使用游标分页"
+// @Success 200 {object} Response "锁定记录列表"
+// @Router /api/v1/security/account-lockouts [get]
+func (c *SecurityController) GetAccountLockouts(ctx *gin.Context) {
+	if c.securityService == nil {
+		c.Error(ctx, http.StatusInternalServerError, "安全防护服务未初始化")
+		return
+	}
+	req, ok := c.pageRequest(ctx)
+	if !ok {
+		return
+	}
+	activeOnly, _ := strconv.ParseBool(ctx.Query("active"))
+
+	lockouts, meta, err := c.securityService.GetAccountLockoutService().ListPage(Services.AccountLockoutFilter{
+		Username:    ctx.Query("username"),
+		IPAddress:   ctx.Query("ip_address"),
+		LockoutType: ctx.Query("lockout_type"),
+		ActiveOnly:  activeOnly,
+	}, req)
+	c.listResult(ctx, "account_lockouts", nil, lockouts, meta, err, "账户锁定记录获取成功")
+}
 
-	// 获取总数
-	var total int64
-	query.Count(&total)
+// GetAccountLockoutStatistics 获取账户锁定统计
+// @Summary 获取账户锁定统计
+// @Description 统计时间段内的锁定数量、类型分布、解锁方式和锁定最多的用户/IP，默认最近7天（仅管理员）
+// @Tags 安全防护
+// @Produce json
+// @Security ApiKeyAuth
+// @Param start_date query string false "开始日期(2006-01-02)"
+// @Param end_date query string false "结束日期(2006-01-02)"
+// @Success 200 {object} Response "锁定统计"
+// @Router /api/v1/security/account-lockouts/stats [get]
+func (c *SecurityController) GetAccountLockoutStatistics(ctx *gin.Context) {
+	if c.securityService == nil {
+		c.Error(ctx, http.StatusInternalServerError, "安全防护服务未初始化")
+		return
+	}
 
-	// 分页查询
-	var attempts []Models.LoginAttempt
-	offset := (page - 1) * limit
-	query.Order("attempt_time DESC").
-		Offset(offset).
-		Limit(limit).
-		Find(&attempts)
+	endDate := time.Now()
+	startDate := endDate.AddDate(0, 0, -7)
+	if value := ctx.Query("start_date"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			c.Error(ctx, http.StatusBadRequest, "开始日期格式错误")
+			return
+		}
+		startDate = parsed
+	}
+	if value := ctx.Query("end_date"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			c.Error(ctx, http.StatusBadRequest, "结束日期格式错误")
+			return
+		}
+		endDate = parsed.AddDate(0, 0, 1)
+	}
 
-	c.Success(ctx, gin.H{
-		"attempts":   attempts,
-		"total":      total,
-		"page":       page,
-		"limit":      limit,
-		"total_pages": (int(total) + limit - 1) / limit,
-	}, "登录尝试记录获取成功")
+	stats, err := c.securityService.GetAccountLockoutService().Statistics(ctx.Request.Context(), startDate, endDate)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取账户锁定统计失败: "+err.Error())
+		return
+	}
+	c.Success(ctx, stats, "账户锁定统计获取成功")
 }
 
-// GetAccountLockouts 获取账户锁定记录
-func (c *SecurityController) GetAccountLockouts(ctx *gin.Context) {
+// UnlockAccount 解除指定的锁定
+// @Summary 解除账户锁定
+// @Description 按锁定记录ID解除锁定，可在请求体中填写原因（仅管理员）
+// @Tags 安全防护
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "锁定记录ID"
+// @Success 200 {object} Response "解锁后的记录"
+// @Failure 404 {object} Response "锁定记录不存在"
+// @Failure 409 {object} Response "锁定已解除或已过期"
+// @Router /api/v1/security/account-lockouts/{id}/unlock [post]
+func (c *SecurityController) UnlockAccount(ctx *gin.Context) {
 	if c.securityService == nil {
 		c.Error(ctx, http.StatusInternalServerError, "安全防护服务未初始化")
 		return
 	}
 
-	// 获取查询参数
-	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, "锁定记录ID无效")
+		return
+	}
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	ctx.ShouldBindJSON(&req)
+	if req.Reason == "" {
+		req.Reason = "管理员解锁"
+	}
+	adminID, _ := strconv.ParseUint(ctx.GetString("user_id"), 10, 64)
+
+	lockout, err := c.securityService.GetAccountLockoutService().Unlock(uint(id), uint(adminID), req.Reason)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.Error(ctx, http.StatusNotFound, "锁定记录不存在")
+	case errors.Is(err, Services.ErrLockoutNotActive):
+		c.Error(ctx, http.StatusConflict, err.Error())
+	case err != nil:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	default:
+		c.Success(ctx, lockout, "账户已解锁")
+	}
+}
 
-	// 构建查询条件
-	query := c.securityService.GetDB().Model(&Models.AccountLockout{})
+// UnlockAccountsBy 按用户名或IP解除锁定
+// @Summary 按用户名或IP解除锁定
+// @Description 解除指定用户名和/或IP的所有生效中的锁定（仅管理员）
+// @Tags 安全防护
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "解除的锁定数量"
+// @Failure 400 {object} Response "用户名和IP地址不能同时为空"
+// @Router /api/v1/security/account-lockouts/unlock [post]
+func (c *SecurityController) UnlockAccountsBy(ctx *gin.Context) {
+	if c.securityService == nil {
+		c.Error(ctx, http.StatusInternalServerError, "安全防护服务未初始化")
+		return
+	}
+
+	var req struct {
+		Username  string `json:"username"`
+		IPAddress string `json:"ip_address"`
+		Reason    string `json:"reason"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil || (req.Username == "" && req.IPAddress == "") {
+		c.Error(ctx, http.StatusBadRequest, "用户名和IP地址不能同时为空")
+		return
+	}
+	if req.Reason == "" {
+		req.Reason = "管理员解锁"
+	}
+	adminID, _ := strconv.ParseUint(ctx.GetString("user_id"), 10, 64)
 
-	// 获取总数
-	var total int64
-	query.Count(&total)
+	unlocked, err := c.securityService.GetAccountLockoutService().UnlockBy(req.Username, req.IPAddress, uint(adminID), req.Reason)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
 
-	// 分页查询
-	var lockouts []Models.AccountLockout
-	offset := (page - 1) * limit
-	query.Order("locked_at DESC").
-		Offset(offset).
-		Limit(limit).
-		Find(&lockouts)
+	c.Success(ctx, gin.H{"unlocked": unlocked}, fmt.Sprintf("已解除 %d 条锁定", unlocked))
+}
 
-	c.Success(ctx, gin.H{
-		"lockouts":   lockouts,
-		"total":      total,
-		"page":       page,
-		"limit":      limit,
-		"total_pages": (int(total) + limit - 1) / limit,
-	}, "账户锁定记录获取成功")
+// UnlockAffectsAdmin 解锁请求是否涉及管理员账户，供双人审批中间件判断是否需要审批
+func (c *SecurityController) UnlockAffectsAdmin(ctx *gin.Context, body []byte) (bool, error) {
+	if c.securityService == nil {
+		return false, nil
+	}
+	var id uint64
+	if param := ctx.Param("id"); param != "" {
+		parsed, err := strconv.ParseUint(param, 10, 64)
+		if err != nil {
+			return false, nil
+		}
+		id = parsed
+	}
+	var req struct {
+		Username  string `json:"username"`
+		IPAddress string `json:"ip_address"`
+	}
+	if id == 0 && len(body) > 0 {
+		json.Unmarshal(body, &req)
+	}
+	return c.securityService.GetAccountLockoutService().LocksAdmin(uint(id), req.Username, req.IPAddress)
+}
+
+// SelfServiceUnlock 通过邮件中的签名链接自助解锁
+// @Summary 自助解锁账户
+// @Description 用户点击锁定通知邮件中的链接解除锁定，链接只能使用一次，无需登录
+// @Tags 安全防护
+// @Produce json
+// @Param token query string true "解锁令牌"
+// @Success 200 {object} Response "账户已解锁"
+// @Failure 400 {object} Response "解锁链接无效、已过期或已使用"
+// @Router /api/v1/security/unlock [get]
+func (c *SecurityController) SelfServiceUnlock(ctx *gin.Context) {
+	if c.securityService == nil {
+		c.Error(ctx, http.StatusInternalServerError, "安全防护服务未初始化")
+		return
+	}
+
+	_, err := c.securityService.GetAccountLockoutService().SelfServiceUnlock(ctx.Query("token"))
+	switch {
+	case errors.Is(err, Services.ErrInvalidUnlockToken), errors.Is(err, Services.ErrUnlockTokenExpired), errors.Is(err, Services.ErrLockoutNotActive):
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+	case err != nil:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	default:
+		c.Success(ctx, nil, "账户已解锁，请重新登录")
+	}
 }
 
 // GetSecurityAlerts 获取安全告警列表
@@ -171,66 +449,457 @@ func (c *SecurityController) GetSecurityAlerts(ctx *gin.Context) {
 		c.Error(ctx, http.StatusInternalServerError, "安全防护服务未初始化")
 		return
 	}
+	req, q, ok := c.listQuery(ctx, securityAlertQuerySpec)
+	if !ok {
+		return
+	}
 
-	// 获取查询参数
-	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	var alerts []Models.SecurityAlert
+	query, order, err := q.Apply(c.securityService.GetDB().WithContext(ctx.Request.Context()).Model(&Models.SecurityAlert{}), req)
+	var meta Utils.PageMeta
+	if err == nil {
+		meta, err = Utils.Paginate(query, req, order, &alerts)
+	}
+	c.listResult(ctx, "security_alerts", q, alerts, meta, err, "安全告警列表获取成功")
+}
 
-	// 构建查询条件
-	query := c.securityService.GetDB().Model(&Models.SecurityAlert{})
+// GetSecurityReports 获取安全报告列表
+func (c *SecurityController) GetSecurityReports(ctx *gin.Context) {
+	if c.securityService == nil {
+		c.Error(ctx, http.StatusInternalServerError, "安全防护服务未初始化")
+		return
+	}
+	req, ok := c.pageRequest(ctx)
+	if !ok {
+		return
+	}
 
-	// 获取总数
-	var total int64
-	query.Count(&total)
+	var reports []Models.SecurityReport
+	query := c.securityService.GetDB().WithContext(ctx.Request.Context()).Model(&Models.SecurityReport{})
+	meta, err := Utils.Paginate(query, req, Utils.PageOrder{Column: "created_at"}, &reports)
+	c.listResult(ctx, "security_reports", nil, reports, meta, err, "安全报告列表获取成功")
+}
 
-	// 分页查询
-	var alerts []Models.SecurityAlert
-	offset := (page - 1) * limit
-	query.Order("created_at DESC").
-		Offset(offset).
-		Limit(limit).
-		Find(&alerts)
+// threatExportMaxLimit 分页导出时每页最多的情报记录数，超过时应使用流式导出
+const threatExportMaxLimit = 1000
+
+// ExportThreatIntelligence 导出威胁情报
+// @Summary 导出威胁情报
+// @Description 按类型、严重程度、更新时间导出活跃的威胁情报指标，支持JSON、CSV和STIX 2.1格式。分页导出时通过 X-Next-Cursor 响应头返回下一页的 after_id；stream=true 时流式导出全部数据（仅管理员）
+// @Tags 安全防护
+// @Produce json
+// @Produce text/csv
+// @Security ApiKeyAuth
+// @Param format query string false "导出格式" Enums(json,csv,stix) default(json)
+// @Param type query string false "指标类型" Enums(ip,domain,url,hash)
+// @Param threat_type query string false "威胁类型"
+// @Param severity query string false "严重程度，多个用逗号分隔"
+// @Param since query string false "只导出该时间之后更新的情报，RFC3339时间或时长（如24h）"
+// @Param after_id query int false "分页游标"
+// @Param limit query int false "每页记录数" default(100)
+// @Param stream query bool false "是否流式导出全部数据"
+// @Success 200 {file} file "威胁情报导出文件"
+// @Router /api/v1/security/threats/export [get]
+func (c *SecurityController) ExportThreatIntelligence(ctx *gin.Context) {
+	if c.securityService == nil {
+		c.Error(ctx, http.StatusInternalServerError, "安全防护服务未初始化")
+		return
+	}
+
+	format := strings.ToLower(ctx.DefaultQuery("format", Services.ThreatIntelFormatJSON))
+	if !Services.IsThreatIntelFormat(format) {
+		c.Error(ctx, http.StatusBadRequest, "导出格式无效，支持 json、csv、stix")
+		return
+	}
+
+	filter := Services.ThreatIntelExportFilter{
+		IndicatorType: strings.ToLower(ctx.Query("type")),
+		ThreatType:    ctx.Query("threat_type"),
+	}
+	if filter.IndicatorType != "" && !Services.IsIndicatorType(filter.IndicatorType) {
+		c.Error(ctx, http.StatusBadRequest, "指标类型无效，支持 ip、domain、url、hash")
+		return
+	}
+	for _, severity := range strings.Split(ctx.Query("severity"), ",") {
+		if severity = strings.TrimSpace(severity); severity != "" {
+			filter.Severities = append(filter.Severities, severity)
+		}
+	}
+	if since := ctx.Query("since"); since != "" {
+		if t, err := time.Parse(time.RFC3339, since); err == nil {
+			filter.Since = t
+		} else if d, err := time.ParseDuration(since); err == nil && d > 0 {
+			filter.Since = time.Now().Add(-d)
+		} else {
+			c.Error(ctx, http.StatusBadRequest, "since 参数无效，应为RFC3339时间或时长")
+			return
+		}
+	}
+	if afterID := ctx.Query("after_id"); afterID != "" {
+		id, err := strconv.ParseUint(afterID, 10, 64)
+		if err != nil {
+			c.Error(ctx, http.StatusBadRequest, "after_id 参数无效")
+			return
+		}
+		filter.AfterID = uint(id)
+	}
+
+	filename := fmt.Sprintf("threat-intel-%s%s", time.Now().Format("20060102-150405"), Services.ThreatIntelFileExtension(format))
+	contentType := Services.ThreatIntelContentType(format)
+
+	// 流式导出全部数据，边查询边写入响应
+	if ctx.Query("stream") == "true" {
+		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+		ctx.Header("Content-Type", contentType)
+		ctx.Status(http.StatusOK)
+		if _, err := c.securityService.ExportThreatIndicators(ctx.Request.Context(), ctx.Writer, format, filter); err != nil {
+			// 响应已开始写入，只能记录错误
+			log.Printf("流式导出威胁情报失败: %v", err)
+		}
+		return
+	}
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > threatExportMaxLimit {
+		c.Error(ctx, http.StatusBadRequest, fmt.Sprintf("limit 参数无效，应为1-%d", threatExportMaxLimit))
+		return
+	}
+	filter.Limit = limit
+
+	var buf bytes.Buffer
+	result, err := c.securityService.ExportThreatIndicators(ctx.Request.Context(), &buf, format, filter)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "导出威胁情报失败: "+err.Error())
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	ctx.Header("X-Record-Count", strconv.Itoa(result.Records))
+	ctx.Header("X-Indicator-Count", strconv.Itoa(result.Indicators))
+	if result.NextCursor > 0 {
+		ctx.Header("X-Next-Cursor", strconv.FormatUint(uint64(result.NextCursor), 10))
+	}
+	ctx.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// SetThreatIntelSharingService 设置威胁情报共享服务
+func (c *SecurityController) SetThreatIntelSharingService(service *Services.ThreatIntelSharingService) {
+	c.sharingService = service
+}
+
+// GetThreatIntelSharingStatus 获取威胁情报推送状态
+// @Summary 获取威胁情报推送状态
+// @Description 获取Webhook推送的游标位置、最近一次推送结果和累计推送数量（仅管理员）
+// @Tags 安全防护
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "威胁情报推送状态"
+// @Router /api/v1/security/threats/share [get]
+func (c *SecurityController) GetThreatIntelSharingStatus(ctx *gin.Context) {
+	if c.sharingService == nil {
+		c.Error(ctx, http.StatusInternalServerError, "威胁情报共享服务未初始化")
+		return
+	}
+
+	c.Success(ctx, c.sharingService.GetStatus(), "威胁情报推送状态获取成功")
+}
+
+// ShareThreatIntelligence 立即推送新增威胁情报
+// @Summary 立即推送新增威胁情报
+// @Description 立即将上次推送之后新增的威胁情报推送到配置的Webhook（仅管理员）
+// @Tags 安全防护
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "推送结果"
+// @Failure 400 {object} Response "未启用威胁情报共享"
+// @Failure 502 {object} Response "推送失败"
+// @Router /api/v1/security/threats/share [post]
+func (c *SecurityController) ShareThreatIntelligence(ctx *gin.Context) {
+	if c.sharingService == nil {
+		c.Error(ctx, http.StatusInternalServerError, "威胁情报共享服务未初始化")
+		return
+	}
+
+	pushed, err := c.sharingService.Push(ctx.Request.Context())
+	if errors.Is(err, Services.ErrThreatIntelSharingDisabled) {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		c.Error(ctx, http.StatusBadGateway, err.Error())
+		return
+	}
 
 	c.Success(ctx, gin.H{
-		"alerts":     alerts,
-		"total":      total,
-		"page":       page,
-		"limit":      limit,
-		"total_pages": (int(total) + limit - 1) / limit,
-	}, "安全告警列表获取成功")
+		"pushed": pushed,
+		"status": c.sharingService.GetStatus(),
+	}, "威胁情报推送成功")
 }
 
-// GetSecurityReports 获取安全报告列表
-func (c *SecurityController) GetSecurityReports(ctx *gin.Context) {
+// GetResponsePlaybooks 获取生效的自动响应剧本
+// @Summary 获取自动响应剧本
+// @Description 获取当前生效的安全事件自动响应剧本及是否启用、是否演练模式（仅管理员）
+// @Tags 安全防护
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "自动响应剧本"
+// @Router /api/v1/security/responses/playbooks [get]
+func (c *SecurityController) GetResponsePlaybooks(ctx *gin.Context) {
 	if c.securityService == nil {
 		c.Error(ctx, http.StatusInternalServerError, "安全防护服务未初始化")
 		return
 	}
 
-	// 获取查询参数
-	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	responseService := c.securityService.GetResponseService()
+	c.Success(ctx, gin.H{
+		"enabled":   responseService.Enabled(),
+		"playbooks": responseService.Playbooks(),
+	}, "自动响应剧本获取成功")
+}
+
+// GetResponseExecutions 获取自动响应执行记录
+// @Summary 获取自动响应执行记录
+// @Description 分页获取剧本动作的执行历史，可按状态筛选（仅管理员）
+// @Tags 安全防护
+// @Produce json
+// @Security ApiKeyAuth
+// @Param status query string false "执行状态" Enums(pending_approval,approved,executed,dry_run,failed,rejected,expired)
+// @Param page query int false "页码" default(1)
+// @Param limit query int false "每页数量" default(20)
+// @Param cursor query string false "上一页返回的 meta.cursor，传入时使用游标分页"
+// @Success 200 {object} Response "执行记录列表"
+// @Router /api/v1/security/responses/executions [get]
+func (c *SecurityController) GetResponseExecutions(ctx *gin.Context) {
+	if c.securityService == nil {
+		c.Error(ctx, http.StatusInternalServerError, "安全防护服务未初始化")
+		return
+	}
+	req, ok := c.pageRequest(ctx)
+	if !ok {
+		return
+	}
 
-	// 构建查询条件
-	query := c.securityService.GetDB().Model(&Models.SecurityReport{})
+	executions, meta, err := c.securityService.GetResponseService().ListExecutionsPage(ctx.Query("status"), req)
+	c.listResult(ctx, "security_response_executions", nil, executions, meta, err, "执行记录获取成功")
+}
 
-	// 获取总数
-	var total int64
-	query.Count(&total)
+// ApproveResponseExecution 审批通过并执行待审批的动作
+// @Summary 审批自动响应动作
+// @Description 审批通过待审批的破坏性动作并立即执行（仅管理员）
+// @Tags 安全防护
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "执行记录ID"
+// @Success 200 {object} Response "执行结果"
+// @Failure 404 {object} Response "执行记录不存在"
+// @Failure 409 {object} Response "不是待审批状态或审批已超时"
+// @Router /api/v1/security/responses/executions/{id}/approve [post]
+func (c *SecurityController) ApproveResponseExecution(ctx *gin.Context) {
+	c.reviewResponseExecution(ctx, true)
+}
 
-	// 分页查询
-	var reports []Models.SecurityReport
-	offset := (page - 1) * limit
-	query.Order("created_at DESC").
-		Offset(offset).
-		Limit(limit).
-		Find(&reports)
+// RejectResponseExecution 拒绝待审批的动作
+// @Summary 拒绝自动响应动作
+// @Description 拒绝待审批的破坏性动作，可在请求体中填写原因（仅管理员）
+// @Tags 安全防护
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "执行记录ID"
+// @Success 200 {object} Response "执行记录"
+// @Failure 404 {object} Response "执行记录不存在"
+// @Failure 409 {object} Response "不是待审批状态或审批已超时"
+// @Router /api/v1/security/responses/executions/{id}/reject [post]
+func (c *SecurityController) RejectResponseExecution(ctx *gin.Context) {
+	c.reviewResponseExecution(ctx, false)
+}
 
-	c.Success(ctx, gin.H{
-		"reports":    reports,
-		"total":      total,
-		"page":       page,
-		"limit":      limit,
-		"total_pages": (int(total) + limit - 1) / limit,
-	}, "安全报告列表获取成功")
+// reviewResponseExecution 审批或拒绝执行记录
+func (c *SecurityController) reviewResponseExecution(ctx *gin.Context, approve bool) {
+	if c.securityService == nil {
+		c.Error(ctx, http.StatusInternalServerError, "安全防护服务未初始化")
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, "执行记录ID无效")
+		return
+	}
+	reviewerID, _ := strconv.ParseUint(ctx.GetString("user_id"), 10, 64)
+
+	responseService := c.securityService.GetResponseService()
+	var execution *Models.SecurityResponseExecution
+	if approve {
+		execution, err = responseService.Approve(ctx.Request.Context(), uint(id), uint(reviewerID))
+	} else {
+		var req struct {
+			Reason string `json:"reason"`
+		}
+		ctx.ShouldBindJSON(&req)
+		execution, err = responseService.Reject(uint(id), uint(reviewerID), req.Reason)
+	}
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.Error(ctx, http.StatusNotFound, "执行记录不存在")
+	case errors.Is(err, Services.ErrResponseExecutionNotPending), errors.Is(err, Services.ErrResponseApprovalExpired):
+		c.Error(ctx, http.StatusConflict, err.Error())
+	case err != nil:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	case approve:
+		c.Success(ctx, execution, "动作已审批执行")
+	default:
+		c.Success(ctx, execution, "动作已拒绝")
+	}
+}
+
+// GetAccessControls 获取访问控制条目列表
+// @Summary 获取访问控制条目
+// @Description 按优先级列出访问控制条目，可按用户筛选（仅管理员）
+// @Tags 安全防护
+// @Produce json
+// @Security ApiKeyAuth
+// @Param user_id query int false "用户ID"
+// @Success 200 {object} Response "访问控制条目列表"
+// @Router /api/v1/security/access-controls [get]
+func (c *SecurityController) GetAccessControls(ctx *gin.Context) {
+	if c.securityService == nil {
+		c.Error(ctx, http.StatusInternalServerError, "安全防护服务未初始化")
+		return
+	}
+
+	var userID uint64
+	if value := ctx.Query("user_id"); value != "" {
+		var err error
+		if userID, err = strconv.ParseUint(value, 10, 64); err != nil {
+			c.Error(ctx, http.StatusBadRequest, "用户ID无效")
+			return
+		}
+	}
+
+	controls, err := c.securityService.ListAccessControls(ctx.Request.Context(), uint(userID))
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Success(ctx, gin.H{"access_controls": controls, "total": len(controls)}, "获取访问控制条目成功")
+}
+
+// GetAccessControl 获取访问控制条目
+// @Summary 获取访问控制条目详情
+// @Description ETag响应头为条目的当前版本，更新时通过If-Match传回（仅管理员）
+// @Tags 安全防护
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "条目ID"
+// @Success 200 {object} Response "访问控制条目"
+// @Failure 404 {object} Response "访问控制条目不存在"
+// @Router /api/v1/security/access-controls/{id} [get]
+func (c *SecurityController) GetAccessControl(ctx *gin.Context) {
+	if c.securityService == nil {
+		c.Error(ctx, http.StatusInternalServerError, "安全防护服务未初始化")
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, "访问控制条目ID无效")
+		return
+	}
+
+	control, err := c.securityService.GetAccessControl(ctx.Request.Context(), uint(id))
+	c.accessControlResult(ctx, control, err, "获取访问控制条目成功")
+}
+
+// CreateAccessControl 创建访问控制条目
+// @Summary 创建访问控制条目
+// @Description 用户ID、资源、操作和权限（allow/deny）必填（仅管理员）
+// @Tags 安全防护
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param entry body Services.AccessControlInput true "访问控制条目"
+// @Success 201 {object} Response "创建的条目"
+// @Failure 400 {object} Response "条目无效"
+// @Router /api/v1/security/access-controls [post]
+func (c *SecurityController) CreateAccessControl(ctx *gin.Context) {
+	if c.securityService == nil {
+		c.Error(ctx, http.StatusInternalServerError, "安全防护服务未初始化")
+		return
+	}
+
+	var input Services.AccessControlInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	control, err := c.securityService.CreateAccessControl(ctx.Request.Context(), input)
+	switch {
+	case errors.Is(err, Services.ErrInvalidAccessControl):
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+	case err != nil:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	default:
+		c.SetETag(ctx, control.Version)
+		c.Created(ctx, control, "访问控制条目已创建")
+	}
+}
+
+// UpdateAccessControl 更新访问控制条目
+// @Summary 更新访问控制条目
+// @Description 只更新传入的字段，使用If-Match或请求体中的version做乐观锁检查（仅管理员）
+// @Tags 安全防护
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "条目ID"
+// @Param If-Match header string false "期望的条目版本，即获取条目时的ETag"
+// @Param entry body Services.AccessControlInput true "要修改的字段"
+// @Success 200 {object} Response "更新后的条目，ETag响应头为新版本"
+// @Failure 404 {object} Response "访问控制条目不存在"
+// @Failure 409 {object} Response "条目已被其他请求修改，返回当前版本"
+// @Router /api/v1/security/access-controls/{id} [put]
+func (c *SecurityController) UpdateAccessControl(ctx *gin.Context) {
+	if c.securityService == nil {
+		c.Error(ctx, http.StatusInternalServerError, "安全防护服务未初始化")
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, "访问控制条目ID无效")
+		return
+	}
+	var input Services.AccessControlInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+	expectedVersion, ok := c.ExpectedVersion(ctx, input.Version)
+	if !ok {
+		return
+	}
+
+	control, err := c.securityService.UpdateAccessControl(ctx.Request.Context(), uint(id), input, expectedVersion)
+	c.accessControlResult(ctx, control, err, "访问控制条目已更新")
+}
+
+// accessControlResult 输出访问控制条目或错误，成功时设置ETag
+func (c *SecurityController) accessControlResult(ctx *gin.Context, control *Models.AccessControl, err error, message string) {
+	var conflict *Models.VersionConflictError
+	switch {
+	case errors.As(err, &conflict):
+		c.VersionConflict(ctx, conflict)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.Error(ctx, http.StatusNotFound, "访问控制条目不存在")
+	case errors.Is(err, Services.ErrInvalidAccessControl):
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+	case err != nil:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	default:
+		c.SetETag(ctx, control.Version)
+		c.Success(ctx, control, message)
+	}
 }
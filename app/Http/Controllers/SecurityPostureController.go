@@ -0,0 +1,74 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Services"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SecurityPostureController 安全态势评分控制器
+//
+// 功能说明：
+// 1. 返回当前加权安全评分、等级和分项明细，供安全仪表盘展示
+// 2. 附带与上一次评分快照的变化和最近一段时间的评分趋势
+// 3. 手动保存评分快照，如修复漏洞后立即记录评分变化
+//
+// 安全特性：
+// - 所有接口需要管理员权限（在路由中配置）
+type SecurityPostureController struct {
+	Controller
+	postureService *Services.SecurityPostureService
+}
+
+// NewSecurityPostureController 创建安全态势评分控制器
+func NewSecurityPostureController(postureService *Services.SecurityPostureService) *SecurityPostureController {
+	return &SecurityPostureController{postureService: postureService}
+}
+
+// SecurityPostureQuery 安全态势评分查询参数
+type SecurityPostureQuery struct {
+	Days int `form:"days"` // 返回最近多少天的评分快照，默认30，最多365
+}
+
+// GetSecurityPosture 获取安全态势评分
+// @Summary 获取安全态势评分
+// @Description 总分为各项分数按权重的加权平均，没有数据的分项不参与计算；history 为评分快照，trend 为与最近一次快照的变化
+// @Tags 安全防护
+// @Produce json
+// @Security ApiKeyAuth
+// @Param days query int false "返回最近多少天的评分快照，默认30，最多365"
+// @Success 200 {object} Response "安全态势评分"
+// @Router /api/v1/security/posture [get]
+func (c *SecurityPostureController) GetSecurityPosture(ctx *gin.Context) {
+	var query SecurityPostureQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	posture, err := c.postureService.Posture(query.Days)
+	if err != nil {
+		log.Printf("计算安全态势评分失败: %v", err)
+		c.Error(ctx, http.StatusInternalServerError, "计算安全态势评分失败")
+		return
+	}
+	c.Success(ctx, posture, "安全态势评分获取成功")
+}
+
+// CreateSecurityPostureSnapshot 保存安全态势评分快照
+// @Summary 保存安全态势评分快照
+// @Tags 安全防护
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 201 {object} Response "保存的评分快照"
+// @Router /api/v1/security/posture/snapshots [post]
+func (c *SecurityPostureController) CreateSecurityPostureSnapshot(ctx *gin.Context) {
+	snapshot, err := c.postureService.RecordSnapshot()
+	if err != nil {
+		log.Printf("保存安全态势评分快照失败: %v", err)
+		c.Error(ctx, http.StatusInternalServerError, "保存安全态势评分快照失败")
+		return
+	}
+	c.Created(ctx, snapshot, "安全态势评分快照已保存")
+}
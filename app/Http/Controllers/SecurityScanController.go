@@ -0,0 +1,102 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Services"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SecurityScanController 漏洞扫描控制器
+//
+// 功能说明：
+// 1. 手动触发漏洞扫描，依次执行依赖漏洞、TLS配置、JWT密钥、CORS策略、开放端口等扫描插件
+// 2. 分页查看历史扫描结果和单次扫描发现的漏洞
+//
+// 安全特性：
+// - 所有接口需要管理员权限（在路由中配置）
+// - 同一时间只执行一次扫描，避免重复探测目标主机
+type SecurityScanController struct {
+	Controller
+	auditService *Services.SecurityAuditService
+}
+
+// NewSecurityScanController 创建漏洞扫描控制器
+func NewSecurityScanController(auditService *Services.SecurityAuditService) *SecurityScanController {
+	return &SecurityScanController{auditService: auditService}
+}
+
+// SecurityScanListQuery 扫描结果查询参数
+type SecurityScanListQuery struct {
+	Page     int `form:"page"`      // 页码，默认1
+	PageSize int `form:"page_size"` // 每页数量，默认10，最多100
+}
+
+// CreateSecurityScan 执行漏洞扫描
+// @Summary 执行漏洞扫描
+// @Description 同步执行所有扫描插件并保存结果；插件执行失败时记录在 errors 中，不影响其他插件
+// @Tags 安全防护
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 201 {object} Response "扫描结果"
+// @Failure 409 {object} Response "已有扫描正在执行"
+// @Router /api/v1/security/scans [post]
+func (c *SecurityScanController) CreateSecurityScan(ctx *gin.Context) {
+	result, err := c.auditService.PerformSecurityScanContext(ctx.Request.Context())
+	if err != nil {
+		if errors.Is(err, Services.ErrSecurityScanInProgress) {
+			c.Error(ctx, http.StatusConflict, err.Error())
+			return
+		}
+		log.Printf("执行漏洞扫描失败: %v", err)
+		c.Error(ctx, http.StatusInternalServerError, "执行漏洞扫描失败")
+		return
+	}
+	c.Created(ctx, result, "漏洞扫描完成")
+}
+
+// GetSecurityScans 获取扫描结果列表
+// @Summary 获取扫描结果列表
+// @Tags 安全防护
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} Response "扫描结果列表"
+// @Router /api/v1/security/scans [get]
+func (c *SecurityScanController) GetSecurityScans(ctx *gin.Context) {
+	page, pageSize := c.ValidatePagination(ctx)
+	results, total, err := c.auditService.GetScanResults(page, pageSize)
+	if err != nil {
+		log.Printf("获取扫描结果失败: %v", err)
+		c.Error(ctx, http.StatusInternalServerError, "获取扫描结果失败")
+		return
+	}
+	c.PaginatedSuccess(ctx, results, total, page, pageSize, "扫描结果获取成功")
+}
+
+// GetSecurityScan 获取扫描结果详情
+// @Summary 获取扫描结果详情
+// @Tags 安全防护
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "扫描ID"
+// @Success 200 {object} Response "扫描结果"
+// @Failure 404 {object} Response "扫描结果不存在"
+// @Router /api/v1/security/scans/{id} [get]
+func (c *SecurityScanController) GetSecurityScan(ctx *gin.Context) {
+	result, err := c.auditService.GetScanResult(ctx.Param("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.NotFound(ctx, "扫描结果不存在")
+			return
+		}
+		log.Printf("获取扫描结果失败: %v", err)
+		c.Error(ctx, http.StatusInternalServerError, "获取扫描结果失败")
+		return
+	}
+	c.Success(ctx, result, "扫描结果获取成功")
+}
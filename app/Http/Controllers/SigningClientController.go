@@ -0,0 +1,229 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SigningClientController 请求签名客户端管理控制器
+//
+// 功能说明：
+// 1. 创建服务间调用使用的签名客户端，客户端以指定用户的身份调用接口
+// 2. 停用、启用和删除客户端
+// 3. 轮换签名密钥，旧密钥在宽限期内仍然有效
+//
+// 安全特性：
+// - 所有接口需要管理员权限（在路由中配置）
+// - 签名密钥只在创建和轮换时返回
+type SigningClientController struct {
+	Controller
+	signingService *Services.RequestSigningService
+}
+
+// NewSigningClientController 创建请求签名客户端管理控制器
+func NewSigningClientController(signingService *Services.RequestSigningService) *SigningClientController {
+	return &SigningClientController{signingService: signingService}
+}
+
+// SigningClientSecretResponse 创建客户端和轮换密钥的响应，签名密钥只在此时返回
+type SigningClientSecretResponse struct {
+	Client *Models.SigningClient `json:"client"`
+	KeyID  string                `json:"key_id"`
+	Secret string                `json:"secret"`
+}
+
+// RotateSigningSecretRequest 轮换签名密钥请求
+type RotateSigningSecretRequest struct {
+	GracePeriod *string `json:"grace_period"` // 旧密钥的有效期，如 30m、24h；为空时使用默认宽限期，为 0 时旧密钥立即失效
+}
+
+// GetSigningClients 获取签名客户端列表
+// @Summary 获取签名客户端列表
+// @Tags 请求签名
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "签名客户端列表"
+// @Router /api/v1/admin/signing-clients [get]
+func (c *SigningClientController) GetSigningClients(ctx *gin.Context) {
+	clients, err := c.signingService.ListClients()
+	if err != nil {
+		c.signingError(ctx, err)
+		return
+	}
+	c.Success(ctx, clients, "签名客户端列表获取成功")
+}
+
+// GetSigningClient 获取签名客户端
+// @Summary 获取签名客户端
+// @Tags 请求签名
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "签名客户端ID"
+// @Success 200 {object} Response "签名客户端"
+// @Failure 404 {object} Response "签名客户端不存在"
+// @Router /api/v1/admin/signing-clients/{id} [get]
+func (c *SigningClientController) GetSigningClient(ctx *gin.Context) {
+	id, ok := c.clientID(ctx)
+	if !ok {
+		return
+	}
+	client, err := c.signingService.GetClient(id)
+	if err != nil {
+		c.signingError(ctx, err)
+		return
+	}
+	c.Success(ctx, client, "签名客户端获取成功")
+}
+
+// CreateSigningClient 创建签名客户端
+// @Summary 创建签名客户端
+// @Description 返回客户端标识和签名密钥，密钥只返回这一次
+// @Tags 请求签名
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param client body Services.SigningClientInput true "签名客户端"
+// @Success 201 {object} Response "签名客户端和密钥"
+// @Failure 400 {object} Response "参数无效"
+// @Router /api/v1/admin/signing-clients [post]
+func (c *SigningClientController) CreateSigningClient(ctx *gin.Context) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "需要登录")
+		return
+	}
+	var input Services.SigningClientInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	client, err := c.signingService.CreateClient(input, userID)
+	if err != nil {
+		c.signingError(ctx, err)
+		return
+	}
+	c.Created(ctx, SigningClientSecretResponse{Client: client, KeyID: client.KeyID, Secret: client.Secret}, "签名客户端已创建")
+}
+
+// UpdateSigningClient 更新签名客户端
+// @Summary 更新签名客户端
+// @Description 可以修改名称、描述和状态，status 为 disabled 时该客户端的签名请求返回401
+// @Tags 请求签名
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "签名客户端ID"
+// @Param client body Services.SigningClientInput true "签名客户端"
+// @Success 200 {object} Response "更新后的签名客户端"
+// @Failure 400 {object} Response "参数无效"
+// @Failure 404 {object} Response "签名客户端不存在"
+// @Router /api/v1/admin/signing-clients/{id} [put]
+func (c *SigningClientController) UpdateSigningClient(ctx *gin.Context) {
+	id, ok := c.clientID(ctx)
+	if !ok {
+		return
+	}
+	var input Services.SigningClientInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	client, err := c.signingService.UpdateClient(id, input)
+	if err != nil {
+		c.signingError(ctx, err)
+		return
+	}
+	c.Success(ctx, client, "签名客户端已更新")
+}
+
+// DeleteSigningClient 删除签名客户端
+// @Summary 删除签名客户端
+// @Tags 请求签名
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "签名客户端ID"
+// @Success 200 {object} Response "删除成功"
+// @Failure 404 {object} Response "签名客户端不存在"
+// @Router /api/v1/admin/signing-clients/{id} [delete]
+func (c *SigningClientController) DeleteSigningClient(ctx *gin.Context) {
+	id, ok := c.clientID(ctx)
+	if !ok {
+		return
+	}
+	if err := c.signingService.DeleteClient(id); err != nil {
+		c.signingError(ctx, err)
+		return
+	}
+	c.Success(ctx, nil, "签名客户端已删除")
+}
+
+// RotateSigningSecret 轮换签名密钥
+// @Summary 轮换签名密钥
+// @Description 生成新密钥并返回，旧密钥在宽限期内仍可验证签名，调用方可以逐步切换
+// @Tags 请求签名
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "签名客户端ID"
+// @Param request body RotateSigningSecretRequest false "旧密钥宽限期"
+// @Success 200 {object} Response "新的签名密钥"
+// @Failure 404 {object} Response "签名客户端不存在"
+// @Router /api/v1/admin/signing-clients/{id}/rotate-secret [post]
+func (c *SigningClientController) RotateSigningSecret(ctx *gin.Context) {
+	id, ok := c.clientID(ctx)
+	if !ok {
+		return
+	}
+	var request RotateSigningSecretRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+			return
+		}
+	}
+	var grace *time.Duration
+	if request.GracePeriod != nil {
+		period, err := time.ParseDuration(*request.GracePeriod)
+		if err != nil {
+			c.Error(ctx, http.StatusBadRequest, "宽限期格式错误，应为 30m、24h 这样的时长")
+			return
+		}
+		grace = &period
+	}
+	client, err := c.signingService.RotateSecret(id, grace)
+	if err != nil {
+		c.signingError(ctx, err)
+		return
+	}
+	c.Success(ctx, SigningClientSecretResponse{Client: client, KeyID: client.KeyID, Secret: client.Secret}, "签名密钥已轮换")
+}
+
+// clientID 解析路径中的签名客户端ID
+func (c *SigningClientController) clientID(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		c.Error(ctx, http.StatusBadRequest, "无效的签名客户端ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// signingError 按错误类型返回状态码
+func (c *SigningClientController) signingError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, Services.ErrSigningClientNotFound):
+		c.Error(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, Services.ErrInvalidSigningClient):
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("签名客户端操作失败: %v", err)
+		c.Error(ctx, http.StatusInternalServerError, "签名客户端操作失败")
+	}
+}
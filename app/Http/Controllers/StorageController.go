@@ -1,7 +1,10 @@
 package Controllers
 
 import (
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Services"
 	"cloud-platform-api/app/Storage"
+	"errors"
 	"net/http"
 	"path/filepath"
 	"strconv"
@@ -42,6 +45,7 @@ func NewStorageController(storageManager *Storage.StorageManager) *StorageContro
 // 6. 返回文件信息（URL、大小、类型等）
 // 7. 支持多文件同时上传
 // 8. 自动创建目录结构
+// 9. 启用用量计量时上传字节数计入当前用户的存储用量，超过本周期限额时返回402
 func (sc *StorageController) UploadFile(c *gin.Context) {
 	// 获取上传的文件
 	file, err := c.FormFile("file")
@@ -65,6 +69,17 @@ func (sc *StorageController) UploadFile(c *gin.Context) {
 		storageType = "public"
 	}
 
+	// 检查存储用量限额
+	metering := Services.DefaultMeteringService()
+	userID, _ := strconv.ParseUint(c.GetString("user_id"), 10, 64)
+	if metering != nil && userID != 0 {
+		var quotaErr *Services.UsageQuotaError
+		if err := metering.Check(Services.UserTenant(uint(userID)), Services.UsageMetricStorageBytes, file.Size); errors.As(err, &quotaErr) {
+			Middleware.AbortWithQuotaExceeded(c, quotaErr)
+			return
+		}
+	}
+
 	// 生成文件名
 	filename := generateUniqueFilename(file.Filename)
 
@@ -97,6 +112,10 @@ func (sc *StorageController) UploadFile(c *gin.Context) {
 		return
 	}
 
+	if metering != nil && userID != 0 {
+		metering.Record(Services.UserTenant(uint(userID)), Services.UsageMetricStorageBytes, file.Size)
+	}
+
 	// 记录日志
 	sc.StorageManager.LogInfo("文件上传成功", map[string]interface{}{
 		"category": "business",
@@ -0,0 +1,419 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TeamController 组织和团队控制器
+//
+// 功能说明：
+// 1. 创建和查询组织，组织所有者在组织下创建团队
+// 2. 查询团队和成员，修改成员角色和移除成员
+// 3. 团队管理员通过邮件邀请成员，被邀请的用户登录后接受邀请加入团队
+//
+// 安全特性：
+// - 所有接口都需要登录（在路由中配置）
+// - 只有团队管理员、组织所有者和系统管理员可以管理成员和邀请
+// - 邀请只能由邀请邮箱对应的用户接受一次
+type TeamController struct {
+	Controller
+	teamService *Services.TeamService
+}
+
+// CreateOrganizationRequest 创建组织请求
+type CreateOrganizationRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+// CreateTeamRequest 创建团队请求
+type CreateTeamRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+// UpdateTeamMemberRequest 修改成员角色请求
+type UpdateTeamMemberRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// InviteTeamMemberRequest 邀请成员请求
+type InviteTeamMemberRequest struct {
+	Email string `json:"email" binding:"required"`
+	Role  string `json:"role"`
+}
+
+// AcceptTeamInvitationRequest 接受邀请请求
+type AcceptTeamInvitationRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// NewTeamController 创建组织和团队控制器
+func NewTeamController(teamService *Services.TeamService) *TeamController {
+	return &TeamController{teamService: teamService}
+}
+
+// ListOrganizations 获取组织列表
+// @Summary 获取组织列表
+// @Description 获取当前用户拥有的组织和所在团队所属的组织，管理员返回全部组织
+// @Tags 组织和团队
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "组织列表"
+// @Router /api/v1/organizations [get]
+func (c *TeamController) ListOrganizations(ctx *gin.Context) {
+	actor, ok := c.actor(ctx)
+	if !ok {
+		return
+	}
+	organizations, err := c.teamService.ListOrganizations(actor)
+	if err != nil {
+		c.teamError(ctx, err)
+		return
+	}
+	c.Success(ctx, organizations, "组织列表获取成功")
+}
+
+// CreateOrganization 创建组织
+// @Summary 创建组织
+// @Description 创建组织，当前用户成为组织所有者
+// @Tags 组织和团队
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param organization body CreateOrganizationRequest true "组织"
+// @Success 201 {object} Response "创建的组织"
+// @Failure 409 {object} Response "组织名称已存在"
+// @Router /api/v1/organizations [post]
+func (c *TeamController) CreateOrganization(ctx *gin.Context) {
+	actor, ok := c.actor(ctx)
+	if !ok {
+		return
+	}
+	var req CreateOrganizationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	organization, err := c.teamService.CreateOrganization(req.Name, req.Description, actor)
+	if err != nil {
+		c.teamError(ctx, err)
+		return
+	}
+	c.Created(ctx, organization, "组织已创建")
+}
+
+// CreateTeam 在组织下创建团队
+// @Summary 创建团队
+// @Description 在组织下创建团队，只有组织所有者和管理员可以创建，创建者成为团队管理员
+// @Tags 组织和团队
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "组织ID"
+// @Param team body CreateTeamRequest true "团队"
+// @Success 201 {object} Response "创建的团队"
+// @Failure 403 {object} Response "无权在该组织下创建团队"
+// @Failure 409 {object} Response "团队名称已存在"
+// @Router /api/v1/organizations/{id}/teams [post]
+func (c *TeamController) CreateTeam(ctx *gin.Context) {
+	actor, ok := c.actor(ctx)
+	if !ok {
+		return
+	}
+	organizationID, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	var req CreateTeamRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	team, err := c.teamService.CreateTeam(organizationID, req.Name, req.Description, actor)
+	if err != nil {
+		c.teamError(ctx, err)
+		return
+	}
+	c.Created(ctx, team, "团队已创建")
+}
+
+// ListTeams 获取团队列表
+// @Summary 获取团队列表
+// @Description 获取当前用户所在的团队和所拥有组织下的团队，管理员返回全部团队
+// @Tags 组织和团队
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "团队列表"
+// @Router /api/v1/teams [get]
+func (c *TeamController) ListTeams(ctx *gin.Context) {
+	actor, ok := c.actor(ctx)
+	if !ok {
+		return
+	}
+	teams, err := c.teamService.ListTeams(actor)
+	if err != nil {
+		c.teamError(ctx, err)
+		return
+	}
+	c.Success(ctx, teams, "团队列表获取成功")
+}
+
+// GetTeam 获取团队详情
+// @Summary 获取团队详情
+// @Description 获取团队及其成员，只有团队成员、组织所有者和管理员可见
+// @Tags 组织和团队
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "团队ID"
+// @Success 200 {object} Response "团队和成员"
+// @Failure 404 {object} Response "团队不存在"
+// @Router /api/v1/teams/{id} [get]
+func (c *TeamController) GetTeam(ctx *gin.Context) {
+	actor, ok := c.actor(ctx)
+	if !ok {
+		return
+	}
+	teamID, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	team, err := c.teamService.GetTeam(teamID, actor)
+	if err != nil {
+		c.teamError(ctx, err)
+		return
+	}
+	c.Success(ctx, team, "团队获取成功")
+}
+
+// UpdateMemberRole 修改成员角色
+// @Summary 修改成员角色
+// @Description 修改成员角色为 admin 或 member，团队至少保留一名管理员
+// @Tags 组织和团队
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "团队ID"
+// @Param user_id path int true "用户ID"
+// @Param member body UpdateTeamMemberRequest true "角色"
+// @Success 200 {object} Response "修改后的成员"
+// @Failure 403 {object} Response "无权管理该团队"
+// @Router /api/v1/teams/{id}/members/{user_id} [put]
+func (c *TeamController) UpdateMemberRole(ctx *gin.Context) {
+	actor, ok := c.actor(ctx)
+	if !ok {
+		return
+	}
+	teamID, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	userID, ok := c.pathID(ctx, "user_id")
+	if !ok {
+		return
+	}
+	var req UpdateTeamMemberRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	member, err := c.teamService.UpdateMemberRole(teamID, userID, req.Role, actor)
+	if err != nil {
+		c.teamError(ctx, err)
+		return
+	}
+	c.Success(ctx, member, "成员角色已修改")
+}
+
+// RemoveMember 移除成员
+// @Summary 移除成员
+// @Description 团队管理员可以移除任何成员，成员可以移除自己以退出团队，团队至少保留一名管理员
+// @Tags 组织和团队
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "团队ID"
+// @Param user_id path int true "用户ID"
+// @Success 200 {object} Response "移除成功"
+// @Router /api/v1/teams/{id}/members/{user_id} [delete]
+func (c *TeamController) RemoveMember(ctx *gin.Context) {
+	actor, ok := c.actor(ctx)
+	if !ok {
+		return
+	}
+	teamID, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	userID, ok := c.pathID(ctx, "user_id")
+	if !ok {
+		return
+	}
+	if err := c.teamService.RemoveMember(teamID, userID, actor); err != nil {
+		c.teamError(ctx, err)
+		return
+	}
+	c.Success(ctx, nil, "成员已移除")
+}
+
+// Invite 邀请成员
+// @Summary 邀请成员
+// @Description 向邮箱发送团队邀请邮件，同一邮箱未接受的旧邀请被替换；响应中的令牌用于邮件服务未配置时手动转交
+// @Tags 组织和团队
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "团队ID"
+// @Param invitation body InviteTeamMemberRequest true "邀请"
+// @Success 201 {object} Response "邀请和令牌"
+// @Failure 409 {object} Response "用户已是团队成员"
+// @Router /api/v1/teams/{id}/invitations [post]
+func (c *TeamController) Invite(ctx *gin.Context) {
+	actor, ok := c.actor(ctx)
+	if !ok {
+		return
+	}
+	teamID, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	var req InviteTeamMemberRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	invitation, token, err := c.teamService.Invite(teamID, req.Email, req.Role, actor)
+	if err != nil {
+		c.teamError(ctx, err)
+		return
+	}
+	c.Created(ctx, gin.H{"invitation": invitation, "token": token}, "邀请已发送")
+}
+
+// ListInvitations 获取团队待接受的邀请
+// @Summary 获取团队邀请
+// @Tags 组织和团队
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "团队ID"
+// @Success 200 {object} Response "未接受且未过期的邀请"
+// @Router /api/v1/teams/{id}/invitations [get]
+func (c *TeamController) ListInvitations(ctx *gin.Context) {
+	actor, ok := c.actor(ctx)
+	if !ok {
+		return
+	}
+	teamID, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	invitations, err := c.teamService.ListInvitations(teamID, actor)
+	if err != nil {
+		c.teamError(ctx, err)
+		return
+	}
+	c.Success(ctx, invitations, "邀请列表获取成功")
+}
+
+// RevokeInvitation 撤销邀请
+// @Summary 撤销邀请
+// @Tags 组织和团队
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "团队ID"
+// @Param invitation_id path int true "邀请ID"
+// @Success 200 {object} Response "撤销成功"
+// @Router /api/v1/teams/{id}/invitations/{invitation_id} [delete]
+func (c *TeamController) RevokeInvitation(ctx *gin.Context) {
+	actor, ok := c.actor(ctx)
+	if !ok {
+		return
+	}
+	teamID, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	invitationID, ok := c.pathID(ctx, "invitation_id")
+	if !ok {
+		return
+	}
+	if err := c.teamService.RevokeInvitation(teamID, invitationID, actor); err != nil {
+		c.teamError(ctx, err)
+		return
+	}
+	c.Success(ctx, nil, "邀请已撤销")
+}
+
+// AcceptInvitation 接受邀请
+// @Summary 接受团队邀请
+// @Description 当前用户凭邀请邮件中的令牌加入团队，用户邮箱必须与邀请邮箱一致，邀请只能使用一次
+// @Tags 组织和团队
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param invitation body AcceptTeamInvitationRequest true "邀请令牌"
+// @Success 200 {object} Response "团队成员"
+// @Failure 403 {object} Response "邀请不是发给当前用户的邮箱"
+// @Failure 404 {object} Response "邀请不存在或已失效"
+// @Router /api/v1/teams/invitations/accept [post]
+func (c *TeamController) AcceptInvitation(ctx *gin.Context) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "需要登录")
+		return
+	}
+	var req AcceptTeamInvitationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	member, err := c.teamService.AcceptInvitation(req.Token, userID)
+	if err != nil {
+		c.teamError(ctx, err)
+		return
+	}
+	c.Success(ctx, member, "已加入团队")
+}
+
+// actor 获取当前用户，未登录时返回401
+func (c *TeamController) actor(ctx *gin.Context) (Services.TeamActor, bool) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "需要登录")
+		return Services.TeamActor{}, false
+	}
+	return Services.TeamActor{UserID: userID, Role: c.GetCurrentUserRole(ctx)}, true
+}
+
+// pathID 解析路径中的ID参数
+func (c *TeamController) pathID(ctx *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param(name), 10, 32)
+	if err != nil || id == 0 {
+		c.Error(ctx, http.StatusBadRequest, "无效的ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// teamError 按错误类型返回响应
+func (c *TeamController) teamError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, Services.ErrOrganizationNotFound), errors.Is(err, Services.ErrTeamNotFound),
+		errors.Is(err, Services.ErrTeamMemberNotFound), errors.Is(err, Services.ErrInvitationNotFound):
+		c.Error(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, Services.ErrTeamForbidden), errors.Is(err, Services.ErrInvitationEmailMismatch):
+		c.Error(ctx, http.StatusForbidden, err.Error())
+	case errors.Is(err, Services.ErrOrganizationNameExists), errors.Is(err, Services.ErrTeamNameExists),
+		errors.Is(err, Services.ErrTeamMemberExists):
+		c.Error(ctx, http.StatusConflict, err.Error())
+	case errors.Is(err, Services.ErrInvalidTeam), errors.Is(err, Services.ErrLastTeamAdmin),
+		errors.Is(err, Services.ErrInvitationExpired):
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+	default:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+}
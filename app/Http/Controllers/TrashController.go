@@ -0,0 +1,132 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TrashController 回收站管理控制器
+type TrashController struct {
+	Controller
+	trashService *Services.TrashService
+}
+
+// NewTrashController 创建回收站管理控制器
+func NewTrashController(service *Services.TrashService) *TrashController {
+	return &TrashController{trashService: service}
+}
+
+// GetTrash 获取回收站中的记录
+// @Summary 获取回收站中的记录
+// @Description 按ID倒序分页列出已删除的用户、告警规则、仪表板或API密钥（仅管理员）
+// @Tags 回收站
+// @Produce json
+// @Security ApiKeyAuth
+// @Param type path string true "类型" Enums(users,alert-rules,dashboards,api-keys)
+// @Success 200 {object} Response "回收站记录列表"
+// @Failure 404 {object} Response "不支持的回收站类型"
+// @Router /api/v1/admin/trash/{type} [get]
+func (c *TrashController) GetTrash(ctx *gin.Context) {
+	req, err := c.ParsePageRequest(ctx)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	items, meta, err := c.trashService.List(ctx.Param("type"), req)
+	if err != nil {
+		c.trashError(ctx, err)
+		return
+	}
+	c.ListSuccess(ctx, items, meta, "回收站记录获取成功")
+}
+
+// RestoreTrash 恢复回收站中的记录
+// @Summary 恢复回收站中的记录
+// @Tags 回收站
+// @Produce json
+// @Security ApiKeyAuth
+// @Param type path string true "类型" Enums(users,alert-rules,dashboards,api-keys)
+// @Param id path int true "记录ID"
+// @Success 200 {object} Response "恢复成功"
+// @Failure 404 {object} Response "回收站中没有该记录"
+// @Failure 409 {object} Response "所属记录在回收站中"
+// @Router /api/v1/admin/trash/{type}/{id}/restore [post]
+func (c *TrashController) RestoreTrash(ctx *gin.Context) {
+	id, ok := c.trashItemID(ctx)
+	if !ok {
+		return
+	}
+	kind := ctx.Param("type")
+	if err := c.trashService.Restore(kind, id); err != nil {
+		c.trashError(ctx, err)
+		return
+	}
+	c.audit(ctx, "trash_restore", kind, id, fmt.Sprintf("从回收站恢复 %s %d", kind, id))
+	c.Success(ctx, gin.H{"type": kind, "id": id}, "记录已恢复")
+}
+
+// ForceDeleteTrash 彻底删除回收站中的记录
+// @Summary 彻底删除回收站中的记录
+// @Description 记录被彻底删除，无法恢复，关联数据按删除策略删除或置空（仅管理员）
+// @Tags 回收站
+// @Produce json
+// @Security ApiKeyAuth
+// @Param type path string true "类型" Enums(users,alert-rules,dashboards,api-keys)
+// @Param id path int true "记录ID"
+// @Success 200 {object} Response "删除成功"
+// @Failure 404 {object} Response "回收站中没有该记录"
+// @Failure 409 {object} Response "存在不允许删除的关联数据"
+// @Router /api/v1/admin/trash/{type}/{id} [delete]
+func (c *TrashController) ForceDeleteTrash(ctx *gin.Context) {
+	id, ok := c.trashItemID(ctx)
+	if !ok {
+		return
+	}
+	kind := ctx.Param("type")
+	if err := c.trashService.ForceDelete(kind, id); err != nil {
+		c.trashError(ctx, err)
+		return
+	}
+	c.audit(ctx, "trash_force_delete", kind, id, fmt.Sprintf("彻底删除回收站中的 %s %d", kind, id))
+	c.Success(ctx, gin.H{"type": kind, "id": id}, "记录已彻底删除")
+}
+
+// trashItemID 解析记录ID，无效时返回400
+func (c *TrashController) trashItemID(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.Error(ctx, http.StatusBadRequest, "记录ID无效")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// audit 记录回收站操作的审计日志，记录失败不影响操作结果
+func (c *TrashController) audit(ctx *gin.Context, action, kind string, id uint, description string) {
+	if Database.DB == nil {
+		return
+	}
+	userID, _ := c.GetCurrentUser(ctx)
+	Services.NewAuditService(Database.DB).LogUserAction(nil, userID, ctx.GetString("username"), action, kind, id, description)
+}
+
+// trashError 回收站错误响应
+func (c *TrashController) trashError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, Services.ErrTrashTypeUnknown), errors.Is(err, Services.ErrTrashItemNotFound):
+		c.Error(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, Services.ErrDeletionRestricted), errors.Is(err, Services.ErrDeletionParentDeleted):
+		c.Error(ctx, http.StatusConflict, err.Error())
+	case errors.Is(err, Utils.ErrInvalidCursor):
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+	default:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,209 @@
+package Controllers
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Services"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UsageController 用量和限额控制器
+//
+// 功能说明：
+// 1. 用户查询自己本周期的用量和限额
+// 2. 管理员查询任意租户的用量，按日期范围查询或导出用量记录用于计费
+// 3. 管理员为租户单独设置或删除限额
+//
+// 安全特性：
+// - 所有接口都需要登录（在路由中配置），管理接口需要管理员权限
+type UsageController struct {
+	Controller
+	meteringService *Services.MeteringService
+}
+
+// SetUsageQuotaRequest 设置限额请求
+type SetUsageQuotaRequest struct {
+	Tenant string `json:"tenant" binding:"required"` // 租户，如 user:12、source:billing
+	Metric string `json:"metric" binding:"required"` // 用量指标
+	Limit  *int64 `json:"limit" binding:"required"`  // 每周期限额，0表示不限制
+}
+
+// NewUsageController 创建用量和限额控制器
+func NewUsageController(meteringService *Services.MeteringService) *UsageController {
+	return &UsageController{meteringService: meteringService}
+}
+
+// GetMyUsage 获取当前用户本周期的用量
+// @Summary 获取我的用量
+// @Description 返回当前用户本周期的API调用、上传字节、通知发送用量和限额，limit 为0表示不限制
+// @Tags 用量计量
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "用量和限额"
+// @Router /api/v1/usage [get]
+func (c *UsageController) GetMyUsage(ctx *gin.Context) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "需要登录")
+		return
+	}
+	c.summary(ctx, Services.UserTenant(userID))
+}
+
+// GetTenantUsage 获取租户本周期的用量
+// @Summary 获取租户用量
+// @Tags 用量计量
+// @Produce json
+// @Security ApiKeyAuth
+// @Param tenant query string true "租户，如 user:12、source:billing"
+// @Success 200 {object} Response "用量和限额"
+// @Router /api/v1/admin/usage [get]
+func (c *UsageController) GetTenantUsage(ctx *gin.Context) {
+	tenant := strings.TrimSpace(ctx.Query("tenant"))
+	if tenant == "" {
+		c.Error(ctx, http.StatusBadRequest, "tenant 参数不能为空")
+		return
+	}
+	c.summary(ctx, tenant)
+}
+
+// GetRecords 查询或导出用量记录
+// @Summary 查询用量记录
+// @Description 按租户、指标和日期范围（UTC日期，包含起止日期）查询每天的用量记录，format=csv 时导出为CSV文件用于计费
+// @Tags 用量计量
+// @Produce json
+// @Produce text/csv
+// @Security ApiKeyAuth
+// @Param tenant query string false "租户"
+// @Param metric query string false "用量指标" Enums(api_calls,storage_bytes,metrics_ingested,notifications_sent)
+// @Param from query string false "开始日期，如 2024-01-01"
+// @Param to query string false "结束日期，如 2024-01-31"
+// @Param format query string false "导出格式" Enums(json,csv) default(json)
+// @Success 200 {object} Response "用量记录"
+// @Router /api/v1/admin/usage/records [get]
+func (c *UsageController) GetRecords(ctx *gin.Context) {
+	filter := Services.UsageFilter{Tenant: strings.TrimSpace(ctx.Query("tenant")), Metric: ctx.Query("metric")}
+	for name, target := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := ctx.Query(name); value != "" {
+			date, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				c.Error(ctx, http.StatusBadRequest, name+" 参数无效，应为 2006-01-02 格式的日期")
+				return
+			}
+			*target = date
+		}
+	}
+
+	switch ctx.DefaultQuery("format", "json") {
+	case "csv":
+		var buf bytes.Buffer
+		if err := c.meteringService.ExportCSV(&buf, filter); err != nil {
+			c.usageError(ctx, err)
+			return
+		}
+		filename := fmt.Sprintf("usage-%s.csv", time.Now().Format("20060102-150405"))
+		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+		ctx.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+	case "json":
+		records, err := c.meteringService.Records(filter)
+		if err != nil {
+			c.usageError(ctx, err)
+			return
+		}
+		c.Success(ctx, records, "用量记录获取成功")
+	default:
+		c.Error(ctx, http.StatusBadRequest, "导出格式无效，支持 json、csv")
+	}
+}
+
+// GetQuotas 获取单独设置的限额
+// @Summary 获取租户限额
+// @Tags 用量计量
+// @Produce json
+// @Security ApiKeyAuth
+// @Param tenant query string false "租户，为空时返回全部租户"
+// @Success 200 {object} Response "限额列表"
+// @Router /api/v1/admin/usage/quotas [get]
+func (c *UsageController) GetQuotas(ctx *gin.Context) {
+	quotas, err := c.meteringService.Quotas(strings.TrimSpace(ctx.Query("tenant")))
+	if err != nil {
+		c.usageError(ctx, err)
+		return
+	}
+	c.Success(ctx, quotas, "限额列表获取成功")
+}
+
+// SetQuota 为租户单独设置限额
+// @Summary 设置租户限额
+// @Description 单独设置的限额优先于默认限额，limit 为0表示不限制
+// @Tags 用量计量
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param quota body SetUsageQuotaRequest true "限额"
+// @Success 200 {object} Response "设置后的限额"
+// @Router /api/v1/admin/usage/quotas [put]
+func (c *UsageController) SetQuota(ctx *gin.Context) {
+	adminID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "需要登录")
+		return
+	}
+	var req SetUsageQuotaRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	quota, err := c.meteringService.SetQuota(strings.TrimSpace(req.Tenant), req.Metric, *req.Limit, adminID)
+	if err != nil {
+		c.usageError(ctx, err)
+		return
+	}
+	c.Success(ctx, quota, "限额已设置")
+}
+
+// DeleteQuota 删除租户单独设置的限额，恢复默认限额
+// @Summary 删除租户限额
+// @Tags 用量计量
+// @Produce json
+// @Security ApiKeyAuth
+// @Param tenant query string true "租户"
+// @Param metric query string true "用量指标"
+// @Success 200 {object} Response "删除成功"
+// @Router /api/v1/admin/usage/quotas [delete]
+func (c *UsageController) DeleteQuota(ctx *gin.Context) {
+	tenant := strings.TrimSpace(ctx.Query("tenant"))
+	if tenant == "" {
+		c.Error(ctx, http.StatusBadRequest, "tenant 参数不能为空")
+		return
+	}
+	if err := c.meteringService.DeleteQuota(tenant, ctx.Query("metric")); err != nil {
+		c.usageError(ctx, err)
+		return
+	}
+	c.Success(ctx, nil, "已恢复默认限额")
+}
+
+// summary 返回租户本周期的用量
+func (c *UsageController) summary(ctx *gin.Context, tenant string) {
+	summaries, err := c.meteringService.Summary(tenant)
+	if err != nil {
+		c.usageError(ctx, err)
+		return
+	}
+	c.Success(ctx, gin.H{"tenant": tenant, "usage": summaries}, "用量获取成功")
+}
+
+// usageError 参数错误返回400，其他错误返回500
+func (c *UsageController) usageError(ctx *gin.Context, err error) {
+	if errors.Is(err, Services.ErrUnknownUsageMetric) || errors.Is(err, Services.ErrInvalidUsageQuota) {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.Error(ctx, http.StatusInternalServerError, "查询用量失败: "+err.Error())
+}
@@ -5,9 +5,14 @@ import (
 	"cloud-platform-api/app/Models"
 	"cloud-platform-api/app/Services"
 	"cloud-platform-api/app/Utils"
+	"errors"
+	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
 )
 
 // UserController 用户控制器
@@ -59,30 +64,51 @@ func NewUserController() *UserController {
 	}
 }
 
+// userSortColumns 用户列表允许的排序字段
+var userSortColumns = map[string]bool{
+	"id":            true,
+	"created_at":    true,
+	"last_login_at": true,
+	"username":      true,
+	"email":         true,
+}
+
 // GetUsers 获取用户列表
 // 功能说明：
 // 1. 获取所有用户列表
-// 2. 支持分页查询
+// 2. 支持页码分页和游标分页（cursor 或 pagination=cursor），游标分页只支持按注册时间或ID排序
 // 3. 支持按角色、状态筛选
 // 4. 支持按用户名、邮箱搜索
-// 5. 支持按注册时间、最后登录时间排序
+// 5. 支持按注册时间、最后登录时间、用户名、邮箱排序，排序字段使用白名单
 // 6. 仅管理员可访问
 func (c *UserController) GetUsers(ctx *gin.Context) {
 	// 权限检查：只有管理员可以查看用户列表
 	userRole := ctx.GetString("user_role")
 	if userRole != "admin" {
-		c.Forbidden(ctx, "只有管理员可以查看用户列表")
+		c.Forbidden(ctx, "user.list_forbidden")
 		return
 	}
 
 	// 获取查询参数
-	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	req, err := c.ParsePageRequest(ctx)
+	if err != nil {
+		c.ValidationError(ctx, "user.invalid_cursor")
+		return
+	}
 	role := ctx.Query("role")
 	status := ctx.Query("status")
 	search := ctx.Query("search")
 	sortBy := ctx.DefaultQuery("sort_by", "created_at")
-	sortOrder := ctx.DefaultQuery("sort_order", "desc")
+	asc := strings.EqualFold(ctx.DefaultQuery("sort_order", "desc"), "asc")
+
+	if !userSortColumns[sortBy] {
+		c.ValidationError(ctx, "user.invalid_sort")
+		return
+	}
+	if req.Mode == Utils.PageModeCursor && sortBy != "created_at" && sortBy != "id" {
+		c.ValidationError(ctx, "user.cursor_sort_unsupported")
+		return
+	}
 
 	// 构建查询条件
 	query := Database.DB.Model(&Models.User{})
@@ -102,33 +128,33 @@ func (c *UserController) GetUsers(ctx *gin.Context) {
 		query = query.Where("username LIKE ? OR email LIKE ?", "%"+search+"%", "%"+search+"%")
 	}
 
-	// 排序
-	orderClause := sortBy + " " + sortOrder
-	query = query.Order(orderClause)
+	// 排序：注册时间和ID由分页排序处理，其他字段先排序再按ID排序
+	order := Utils.PageOrder{Asc: asc}
+	switch sortBy {
+	case "created_at":
+		order.Column = sortBy
+	case "id":
+	default:
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: sortBy}, Desc: !asc})
+	}
 
-	// 分页
-	offset := (page - 1) * limit
 	var users []Models.User
-	var total int64
-
-	query.Count(&total)
-	query.Offset(offset).Limit(limit).Find(&users)
+	meta, err := Utils.Paginate(query, req, order, &users)
+	if errors.Is(err, Utils.ErrInvalidCursor) {
+		c.ValidationError(ctx, "user.invalid_cursor")
+		return
+	}
+	if err != nil {
+		c.ServerError(ctx, "user.list_failed")
+		return
+	}
 
 	// 清除敏感信息
 	for i := range users {
 		users[i].Password = ""
 	}
 
-	// 返回结果
-	c.Success(ctx, gin.H{
-		"users": users,
-		"pagination": gin.H{
-			"page":  page,
-			"limit": limit,
-			"total": total,
-			"pages": (total + int64(limit) - 1) / int64(limit),
-		},
-	}, "用户列表获取成功")
+	c.ListSuccess(ctx, users, meta, "user.list_success")
 }
 
 // GetUser 获取单个用户
@@ -141,7 +167,7 @@ func (c *UserController) GetUser(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		c.ValidationError(ctx, "无效的用户ID")
+		c.ValidationError(ctx, "user.invalid_id")
 		return
 	}
 
@@ -151,19 +177,20 @@ func (c *UserController) GetUser(ctx *gin.Context) {
 
 	// 权限检查：只能查看自己的信息或管理员可以查看所有用户
 	if currentUserRole != "admin" && currentUserID != idStr {
-		c.Forbidden(ctx, "只能查看自己的用户信息")
+		c.Forbidden(ctx, "user.view_forbidden")
 		return
 	}
 
 	// 查找用户
 	var user Models.User
 	if err := Database.DB.Preload("Posts").First(&user, id).Error; err != nil {
-		c.NotFound(ctx, "用户不存在")
+		c.NotFound(ctx, "user.not_found")
 		return
 	}
 
 	// 清除敏感信息
 	user.Password = ""
+	c.SetETag(ctx, user.Version)
 
 	// 获取用户统计信息
 	var postCount int64
@@ -177,7 +204,7 @@ func (c *UserController) GetUser(ctx *gin.Context) {
 		},
 	}
 
-	c.Success(ctx, response, "用户信息获取成功")
+	c.Success(ctx, response, "user.show_success")
 }
 
 // UpdateUser 更新用户
@@ -187,11 +214,12 @@ func (c *UserController) GetUser(ctx *gin.Context) {
 // 3. 验证用户权限（只能更新自己的信息或管理员可以更新所有用户）
 // 4. 检查字段唯一性约束
 // 5. 支持密码更新（需要验证原密码）
+// 6. 支持 If-Match 乐观锁，用户已被其他请求修改时返回409和当前版本
 func (c *UserController) UpdateUser(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		c.ValidationError(ctx, "无效的用户ID")
+		c.ValidationError(ctx, "user.invalid_id")
 		return
 	}
 
@@ -201,7 +229,7 @@ func (c *UserController) UpdateUser(ctx *gin.Context) {
 
 	// 权限检查：只能更新自己的信息或管理员可以更新所有用户
 	if currentUserRole != "admin" && currentUserID != idStr {
-		c.Forbidden(ctx, "只能更新自己的用户信息")
+		c.Forbidden(ctx, "user.update_forbidden")
 		return
 	}
 
@@ -214,17 +242,22 @@ func (c *UserController) UpdateUser(ctx *gin.Context) {
 		NewPassword string `json:"new_password"`
 		Status      *int   `json:"status"`
 		Role        string `json:"role"`
+		Version     uint   `json:"version"` // 期望的版本，可用 If-Match 请求头代替
 	}
 
 	if err := ctx.ShouldBindJSON(&request); err != nil {
 		c.ValidationError(ctx, err.Error())
 		return
 	}
+	expectedVersion, ok := c.ExpectedVersion(ctx, request.Version)
+	if !ok {
+		return
+	}
 
 	// 查找用户
 	var user Models.User
 	if err := Database.DB.First(&user, id).Error; err != nil {
-		c.NotFound(ctx, "用户不存在")
+		c.NotFound(ctx, "user.not_found")
 		return
 	}
 
@@ -232,7 +265,7 @@ func (c *UserController) UpdateUser(ctx *gin.Context) {
 	if request.Username != "" && request.Username != user.Username {
 		var existingUser Models.User
 		if err := Database.DB.Where("username = ? AND id != ?", request.Username, id).First(&existingUser).Error; err == nil {
-			c.ValidationError(ctx, "用户名已存在")
+			c.ValidationError(ctx, "user.username_exists")
 			return
 		}
 	}
@@ -241,7 +274,7 @@ func (c *UserController) UpdateUser(ctx *gin.Context) {
 	if request.Email != "" && request.Email != user.Email {
 		var existingUser Models.User
 		if err := Database.DB.Where("email = ? AND id != ?", request.Email, id).First(&existingUser).Error; err == nil {
-			c.ValidationError(ctx, "邮箱已存在")
+			c.ValidationError(ctx, "user.email_exists")
 			return
 		}
 	}
@@ -261,13 +294,13 @@ func (c *UserController) UpdateUser(ctx *gin.Context) {
 	if request.NewPassword != "" {
 		// 验证原密码
 		if !Utils.CheckPassword(request.OldPassword, user.Password) {
-			c.ValidationError(ctx, "原密码错误")
+			c.ValidationError(ctx, "user.old_password_incorrect")
 			return
 		}
 
 		// 设置新密码
 		if err := user.SetPassword(request.NewPassword); err != nil {
-			c.ServerError(ctx, "密码更新失败")
+			c.ServerError(ctx, "user.password_update_failed")
 			return
 		}
 	}
@@ -282,21 +315,36 @@ func (c *UserController) UpdateUser(ctx *gin.Context) {
 		}
 	}
 
-	// 保存更新
-	if err := Database.DB.Save(&user).Error; err != nil {
-		c.ServerError(ctx, "更新用户信息失败")
+	// 保存更新，用户已被其他请求修改时返回409和当前版本
+	err = Models.SaveWithVersion(Database.DB, &user, expectedVersion)
+	var conflict *Models.VersionConflictError
+	if errors.As(err, &conflict) {
+		c.VersionConflict(ctx, conflict)
+		return
+	}
+	if err != nil {
+		c.ServerError(ctx, "user.update_failed")
 		return
 	}
 
+	// 记录更新操作到审计日志，用户活动时间线据此展示资料修改和密码修改
+	auditService := Services.NewAuditService(Database.DB)
+	currentUserIDUint, _ := strconv.ParseUint(currentUserID, 10, 32)
+	auditService.LogUserAction(nil, uint(currentUserIDUint), ctx.GetString("username"), "update_user", "user", user.ID, "更新用户信息")
+	if request.NewPassword != "" {
+		auditService.LogUserAction(nil, uint(currentUserIDUint), ctx.GetString("username"), "password_change", "user", user.ID, "密码修改成功")
+	}
+
 	// 清除敏感信息
 	user.Password = ""
 
-	c.Success(ctx, user, "用户信息更新成功")
+	c.SetETag(ctx, user.Version)
+	c.Success(ctx, user, "user.updated")
 }
 
 // DeleteUser 删除用户
 // 功能说明：
-// 1. 删除指定的用户
+// 1. 删除指定的用户，用户进入回收站，管理员可以恢复
 // 2. 仅管理员可以删除用户
 // 3. 检查用户是否有文章（有文章时不允许删除）
 // 4. 可选择是否同时删除用户的文章
@@ -305,34 +353,34 @@ func (c *UserController) DeleteUser(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		c.ValidationError(ctx, "无效的用户ID")
+		c.ValidationError(ctx, "user.invalid_id")
 		return
 	}
 
 	// 权限检查：只有管理员可以删除用户
 	userRole := ctx.GetString("user_role")
 	if userRole != "admin" {
-		c.Forbidden(ctx, "只有管理员可以删除用户")
+		c.Forbidden(ctx, "user.delete_forbidden")
 		return
 	}
 
 	// 不能删除自己
 	currentUserID := ctx.GetString("user_id")
 	if currentUserID == idStr {
-		c.ValidationError(ctx, "不能删除自己的账户")
+		c.ValidationError(ctx, "user.cannot_delete_self")
 		return
 	}
 
 	// 查找用户
 	var user Models.User
 	if err := Database.DB.First(&user, id).Error; err != nil {
-		c.NotFound(ctx, "用户不存在")
+		c.NotFound(ctx, "user.not_found")
 		return
 	}
 
 	// 不能删除超级管理员
 	if user.Role == "admin" {
-		c.ValidationError(ctx, "不能删除管理员账户")
+		c.ValidationError(ctx, "user.cannot_delete_admin")
 		return
 	}
 
@@ -343,31 +391,21 @@ func (c *UserController) DeleteUser(ctx *gin.Context) {
 		// 检查是否强制删除
 		forceDelete := ctx.Query("force") == "true"
 		if !forceDelete {
-			c.ValidationError(ctx, "该用户有文章，无法删除。如需强制删除，请添加force=true参数")
+			c.ValidationError(ctx, c.TransChoice(ctx, "user.has_posts", postCount))
 			return
 		}
 
 		// 强制删除时，先删除用户的文章
 		if err := Database.DB.Where("user_id = ?", id).Delete(&Models.Post{}).Error; err != nil {
-			c.ServerError(ctx, "删除用户文章失败")
+			c.ServerError(ctx, "user.delete_posts_failed")
 			return
 		}
 	}
 
-	// 检查用户是否有评论（如果有评论表）
-	// var commentCount int64
-	// Database.DB.Model(&Models.Comment{}).Where("user_id = ?", id).Count(&commentCount)
-	// if commentCount > 0 {
-	//     // 删除用户评论
-	//     Database.DB.Where("user_id = ?", id).Delete(&Models.Comment{})
-	// }
-
-	// 删除用户相关的其他数据（如用户设置、偏好等）
-	// 这里可以根据实际的数据模型进行扩展
-
 	// 删除用户
-	if err := Database.DB.Delete(&user).Error; err != nil {
-		c.ServerError(ctx, "删除用户失败")
+	// 用户及API密钥等关联数据进入回收站，关联数据的删除策略见 DeletionService，删除后撤销用户已签发的token
+	if err := Services.DefaultDeletionService(Database.DB).Delete(Services.TrashUsers, user.ID); err != nil {
+		c.ServerError(ctx, "user.delete_failed")
 		return
 	}
 
@@ -379,7 +417,7 @@ func (c *UserController) DeleteUser(ctx *gin.Context) {
 	c.Success(ctx, gin.H{
 		"deleted_user_id": id,
 		"deleted_posts":   postCount,
-	}, "用户删除成功")
+	}, "user.deleted")
 }
 
 // GetUserPosts 获取用户的文章列表
@@ -392,7 +430,7 @@ func (c *UserController) GetUserPosts(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		c.ValidationError(ctx, "无效的用户ID")
+		c.ValidationError(ctx, "user.invalid_id")
 		return
 	}
 
@@ -435,5 +473,85 @@ func (c *UserController) GetUserPosts(ctx *gin.Context) {
 			"total": total,
 			"pages": (total + int64(limit) - 1) / int64(limit),
 		},
-	}, "用户文章列表获取成功")
+	}, "user.posts_success")
+}
+
+// GetMyLogins 获取当前用户的登录记录
+// 功能说明：
+// 1. 返回当前用户最近的登录尝试（时间、IP、国家、设备、是否成功），按时间倒序
+// 2. 支持页码分页和游标分页，参数同其他列表接口
+// 3. 用户可以据此发现不是本人的登录，新设备登录时还会收到提醒邮件
+func (c *UserController) GetMyLogins(ctx *gin.Context) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "common.unauthorized")
+		return
+	}
+	req, err := c.ParsePageRequest(ctx)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entries, meta, err := Services.DefaultLoginHistoryService(Database.DB).History(userID, req)
+	if err != nil {
+		if errors.Is(err, Utils.ErrInvalidCursor) {
+			c.Error(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.ServerError(ctx, "user.login_history_failed")
+		return
+	}
+	c.ListSuccess(ctx, entries, meta, "user.login_history_success")
+}
+
+// GetMyActivity 获取当前用户的账户活动时间线
+// 功能说明：
+// 1. 汇总登录、密码修改、API密钥操作、资料修改和安全事件，按时间倒序返回，用于用户的"安全活动"页面
+// 2. type 按活动类型筛选（login、password、api_key、profile、security），可逗号分隔或重复传入，默认全部类型
+// 3. since、until 按时间范围筛选（RFC3339格式）
+// 4. 支持页码分页和游标分页，参数同其他列表接口
+func (c *UserController) GetMyActivity(ctx *gin.Context) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "common.unauthorized")
+		return
+	}
+	req, err := c.ParsePageRequest(ctx)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var filter Services.ActivityFilter
+	for _, value := range ctx.QueryArray("type") {
+		for _, activityType := range strings.Split(value, ",") {
+			if activityType = strings.TrimSpace(activityType); activityType != "" {
+				filter.Types = append(filter.Types, activityType)
+			}
+		}
+	}
+	for param, target := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := ctx.Query(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.Error(ctx, http.StatusBadRequest, "时间格式无效: "+param)
+			return
+		}
+		*target = &parsed
+	}
+
+	events, meta, err := Services.NewActivityTimelineService(Database.DB).Timeline(userID, filter, req)
+	if err != nil {
+		if errors.Is(err, Services.ErrInvalidActivityType) || errors.Is(err, Utils.ErrInvalidCursor) {
+			c.Error(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.ServerError(ctx, "user.activity_failed")
+		return
+	}
+	c.ListSuccess(ctx, events, meta, "user.activity_success")
 }
@@ -0,0 +1,119 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserSettingsController 用户偏好设置控制器
+//
+// 功能说明：
+// 1. 查询当前用户的全部设置和设置项定义（类型、默认值、可选值）
+// 2. 批量修改设置，任一项无效时都不保存
+// 3. 将单个设置项恢复为默认值
+//
+// 安全特性：
+// - 所有接口都需要登录（在路由中配置），只能访问自己的设置
+type UserSettingsController struct {
+	Controller
+	settingsService *Services.UserSettingsService
+}
+
+// NewUserSettingsController 创建用户偏好设置控制器
+func NewUserSettingsController(settingsService *Services.UserSettingsService) *UserSettingsController {
+	return &UserSettingsController{settingsService: settingsService}
+}
+
+// GetSettings 获取当前用户的设置
+// @Summary 获取偏好设置
+// @Description 返回当前用户的全部设置（未修改的设置项为默认值）和设置项定义
+// @Tags 用户设置
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "设置和设置项定义"
+// @Router /api/v1/users/me/settings [get]
+func (c *UserSettingsController) GetSettings(ctx *gin.Context) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "需要登录")
+		return
+	}
+
+	settings, err := c.settingsService.Get(userID)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取偏好设置失败: "+err.Error())
+		return
+	}
+	c.Success(ctx, gin.H{
+		"settings":    settings,
+		"definitions": c.settingsService.Definitions(),
+	}, "偏好设置获取成功")
+}
+
+// UpdateSettings 修改当前用户的设置
+// @Summary 修改偏好设置
+// @Description 请求体为设置项到新值的映射，如 {"notifications.sms": false, "timezone": "Europe/Berlin"}
+// @Tags 用户设置
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param settings body object true "要修改的设置项"
+// @Success 200 {object} Response "修改后的全部设置"
+// @Failure 400 {object} Response "未知的设置项或设置值无效"
+// @Router /api/v1/users/me/settings [patch]
+func (c *UserSettingsController) UpdateSettings(ctx *gin.Context) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "需要登录")
+		return
+	}
+
+	var values map[string]interface{}
+	if err := ctx.ShouldBindJSON(&values); err != nil || len(values) == 0 {
+		c.Error(ctx, http.StatusBadRequest, "请求体必须是非空的设置项对象")
+		return
+	}
+
+	settings, err := c.settingsService.Update(userID, values)
+	if err != nil {
+		c.settingsError(ctx, err)
+		return
+	}
+	c.Success(ctx, gin.H{"settings": settings}, "偏好设置已保存")
+}
+
+// ResetSetting 将设置项恢复为默认值
+// @Summary 恢复默认设置
+// @Tags 用户设置
+// @Produce json
+// @Security ApiKeyAuth
+// @Param key path string true "设置项，如 notifications.sms"
+// @Success 200 {object} Response "恢复后的全部设置"
+// @Failure 400 {object} Response "未知的设置项"
+// @Router /api/v1/users/me/settings/{key} [delete]
+func (c *UserSettingsController) ResetSetting(ctx *gin.Context) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Unauthorized(ctx, "需要登录")
+		return
+	}
+
+	settings, err := c.settingsService.Reset(userID, ctx.Param("key"))
+	if err != nil {
+		c.settingsError(ctx, err)
+		return
+	}
+	c.Success(ctx, gin.H{"settings": settings}, "已恢复默认设置")
+}
+
+// settingsError 设置项错误返回400，其他错误返回500
+func (c *UserSettingsController) settingsError(ctx *gin.Context, err error) {
+	if errors.Is(err, Services.ErrUnknownSetting) || errors.Is(err, Services.ErrInvalidSetting) {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.Error(ctx, http.StatusInternalServerError, "保存偏好设置失败: "+err.Error())
+}
@@ -0,0 +1,445 @@
+package Controllers
+
+import (
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Utils"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookController Webhook订阅管理控制器
+type WebhookController struct {
+	Controller
+	webhookService *Services.WebhookService
+}
+
+// NewWebhookController 创建Webhook订阅管理控制器
+func NewWebhookController(webhookService *Services.WebhookService) *WebhookController {
+	return &WebhookController{webhookService: webhookService}
+}
+
+// WebhookSecretResponse 创建订阅和轮换密钥的响应，签名密钥只在此时返回
+type WebhookSecretResponse struct {
+	Subscription *Models.WebhookSubscription `json:"subscription"`
+	Secret       string                      `json:"secret"`
+}
+
+// WebhookSubscriptionQuerySpec 订阅列表可筛选、排序和返回的字段
+var WebhookSubscriptionQuerySpec = Utils.QuerySpec{
+	Fields: map[string]Utils.QueryField{
+		"id":                   {Column: "id", Type: Utils.QueryInt, Filter: true, Sort: true},
+		"name":                 {Column: "name", Type: Utils.QueryString, Filter: true, Sort: true},
+		"description":          {Column: "description", Type: Utils.QueryString},
+		"url":                  {Column: "url", Type: Utils.QueryString, Filter: true},
+		"event_types":          {Column: "event_types", Type: Utils.QueryString, Filter: true},
+		"filter":               {Column: "filter", Type: Utils.QueryString},
+		"status":               {Column: "status", Type: Utils.QueryString, Filter: true},
+		"created_by":           {Column: "created_by", Type: Utils.QueryInt, Filter: true},
+		"consecutive_failures": {Column: "consecutive_failures", Type: Utils.QueryInt, Filter: true, Sort: true},
+		"last_delivery_status": {Column: "last_delivery_status", Type: Utils.QueryString, Filter: true},
+		"last_delivery_at":     {Column: "last_delivery_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+		"paused_at":            {Column: "paused_at", Type: Utils.QueryTime},
+		"created_at":           {Column: "created_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+		"updated_at":           {Column: "updated_at", Type: Utils.QueryTime},
+	},
+	DefaultSort: "-created_at",
+}
+
+// WebhookDeliveryQuerySpec 投递记录列表可筛选、排序和返回的字段
+var WebhookDeliveryQuerySpec = Utils.QuerySpec{
+	Fields: map[string]Utils.QueryField{
+		"id":              {Column: "id", Type: Utils.QueryInt, Filter: true, Sort: true},
+		"delivery_id":     {Column: "delivery_id", Type: Utils.QueryString, Filter: true},
+		"subscription_id": {Column: "subscription_id", Type: Utils.QueryInt},
+		"event_id":        {Column: "event_id", Type: Utils.QueryString, Filter: true},
+		"event_type":      {Column: "event_type", Type: Utils.QueryString, Filter: true, Sort: true},
+		"payload":         {Column: "payload", Type: Utils.QueryString},
+		"status":          {Column: "status", Type: Utils.QueryString, Filter: true},
+		"attempts":        {Column: "attempts", Type: Utils.QueryInt, Filter: true, Sort: true},
+		"next_attempt_at": {Column: "next_attempt_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+		"response_status": {Column: "response_status", Type: Utils.QueryInt, Filter: true},
+		"response_body":   {Column: "response_body", Type: Utils.QueryString},
+		"duration_ms":     {Column: "duration_ms", Type: Utils.QueryInt, Filter: true, Sort: true},
+		"last_error":      {Column: "last_error", Type: Utils.QueryString},
+		"delivered_at":    {Column: "delivered_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+		"created_at":      {Column: "created_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+		"updated_at":      {Column: "updated_at", Type: Utils.QueryTime},
+	},
+	DefaultSort: "-created_at",
+}
+
+// GetEventTypes 获取可订阅的事件类型
+// @Summary 获取可订阅的事件类型
+// @Tags Webhook订阅
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} Response "事件类型列表"
+// @Router /api/v1/admin/webhooks/event-types [get]
+func (c *WebhookController) GetEventTypes(ctx *gin.Context) {
+	c.Success(ctx, Services.WebhookEventTypes, "事件类型获取成功")
+}
+
+// GetSubscriptions 获取Webhook订阅列表
+// @Summary 获取Webhook订阅列表
+// @Description 分页查询Webhook订阅，支持 filter[status][eq]=paused 等筛选（仅管理员）
+// @Tags Webhook订阅
+// @Produce json
+// @Security ApiKeyAuth
+// @Param filter[status][eq] query string false "按状态筛选(active/paused)"
+// @Param sort query string false "排序字段，逗号分隔，前缀-表示降序" default(-created_at)
+// @Param fields query string false "返回字段，逗号分隔"
+// @Param page query int false "页码" default(1)
+// @Param limit query int false "每页数量" default(20)
+// @Param cursor query string false "上一页返回的 meta.cursor，传入时使用游标分页"
+// @Success 200 {object} Response "订阅列表"
+// @Failure 400 {object} Response "查询参数无效"
+// @Router /api/v1/admin/webhooks [get]
+func (c *WebhookController) GetSubscriptions(ctx *gin.Context) {
+	req, err := c.ParsePageRequest(ctx)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	q, err := Utils.ParseListQuery(ctx.Request.URL.Query(), WebhookSubscriptionQuerySpec)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	subscriptions, meta, err := c.webhookService.ListSubscriptions(q, req)
+	if errors.Is(err, Utils.ErrInvalidCursor) || errors.Is(err, Utils.ErrInvalidQuery) {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取Webhook订阅失败: "+err.Error())
+		return
+	}
+
+	data, err := q.Project(subscriptions)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取Webhook订阅失败: "+err.Error())
+		return
+	}
+	c.ListSuccess(ctx, data, meta, "Webhook订阅获取成功")
+}
+
+// GetSubscription 获取Webhook订阅详情
+// @Summary 获取Webhook订阅详情
+// @Tags Webhook订阅
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "订阅ID"
+// @Success 200 {object} Response "订阅"
+// @Failure 404 {object} Response "订阅不存在"
+// @Router /api/v1/admin/webhooks/{id} [get]
+func (c *WebhookController) GetSubscription(ctx *gin.Context) {
+	id, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	subscription, err := c.webhookService.GetSubscription(id)
+	if err != nil {
+		c.webhookError(ctx, err)
+		return
+	}
+	c.Success(ctx, subscription, "Webhook订阅获取成功")
+}
+
+// CreateSubscription 创建Webhook订阅
+// @Summary 创建Webhook订阅
+// @Description 创建订阅，未指定签名密钥时自动生成；密钥只在创建和轮换时返回（仅管理员）
+// @Tags Webhook订阅
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param subscription body Services.WebhookSubscriptionInput true "订阅"
+// @Success 201 {object} Response "创建的订阅和签名密钥"
+// @Failure 400 {object} Response "参数无效"
+// @Router /api/v1/admin/webhooks [post]
+func (c *WebhookController) CreateSubscription(ctx *gin.Context) {
+	userID, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		c.Error(ctx, http.StatusUnauthorized, "需要登录")
+		return
+	}
+	var input Services.WebhookSubscriptionInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	subscription, err := c.webhookService.CreateSubscription(input, userID)
+	if err != nil {
+		c.webhookError(ctx, err)
+		return
+	}
+	c.Created(ctx, WebhookSecretResponse{Subscription: subscription, Secret: subscription.Secret}, "Webhook订阅已创建")
+}
+
+// UpdateSubscription 更新Webhook订阅
+// @Summary 更新Webhook订阅
+// @Description 更新订阅的地址、事件类型和筛选条件，secret 为空时保留原密钥（仅管理员）
+// @Tags Webhook订阅
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "订阅ID"
+// @Param subscription body Services.WebhookSubscriptionInput true "订阅"
+// @Success 200 {object} Response "更新后的订阅"
+// @Router /api/v1/admin/webhooks/{id} [put]
+func (c *WebhookController) UpdateSubscription(ctx *gin.Context) {
+	id, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	var input Services.WebhookSubscriptionInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.Error(ctx, http.StatusBadRequest, "参数绑定失败: "+err.Error())
+		return
+	}
+	subscription, err := c.webhookService.UpdateSubscription(id, input)
+	if err != nil {
+		c.webhookError(ctx, err)
+		return
+	}
+	c.Success(ctx, subscription, "Webhook订阅已更新")
+}
+
+// DeleteSubscription 删除Webhook订阅
+// @Summary 删除Webhook订阅
+// @Description 删除订阅及其投递记录（仅管理员）
+// @Tags Webhook订阅
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "订阅ID"
+// @Success 200 {object} Response "删除成功"
+// @Router /api/v1/admin/webhooks/{id} [delete]
+func (c *WebhookController) DeleteSubscription(ctx *gin.Context) {
+	id, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	if err := c.webhookService.DeleteSubscription(id); err != nil {
+		c.webhookError(ctx, err)
+		return
+	}
+	c.Success(ctx, nil, "Webhook订阅已删除")
+}
+
+// PauseSubscription 暂停Webhook订阅
+// @Summary 暂停Webhook订阅
+// @Description 暂停期间的事件照常记录，恢复后补发（仅管理员）
+// @Tags Webhook订阅
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "订阅ID"
+// @Success 200 {object} Response "暂停后的订阅"
+// @Router /api/v1/admin/webhooks/{id}/pause [post]
+func (c *WebhookController) PauseSubscription(ctx *gin.Context) {
+	id, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	subscription, err := c.webhookService.PauseSubscription(id)
+	if err != nil {
+		c.webhookError(ctx, err)
+		return
+	}
+	c.Success(ctx, subscription, "Webhook订阅已暂停")
+}
+
+// ResumeSubscription 恢复Webhook订阅
+// @Summary 恢复Webhook订阅
+// @Description 恢复投递并补发暂停期间积压的事件（仅管理员）
+// @Tags Webhook订阅
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "订阅ID"
+// @Success 200 {object} Response "恢复后的订阅"
+// @Router /api/v1/admin/webhooks/{id}/resume [post]
+func (c *WebhookController) ResumeSubscription(ctx *gin.Context) {
+	id, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	subscription, err := c.webhookService.ResumeSubscription(id)
+	if err != nil {
+		c.webhookError(ctx, err)
+		return
+	}
+	c.Success(ctx, subscription, "Webhook订阅已恢复")
+}
+
+// RotateSecret 轮换签名密钥
+// @Summary 轮换签名密钥
+// @Description 生成新的签名密钥并返回，之后发送的请求使用新密钥签名（仅管理员）
+// @Tags Webhook订阅
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "订阅ID"
+// @Success 200 {object} Response "新的签名密钥"
+// @Router /api/v1/admin/webhooks/{id}/rotate-secret [post]
+func (c *WebhookController) RotateSecret(ctx *gin.Context) {
+	id, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	subscription, err := c.webhookService.RotateSecret(id)
+	if err != nil {
+		c.webhookError(ctx, err)
+		return
+	}
+	c.Success(ctx, WebhookSecretResponse{Subscription: subscription, Secret: subscription.Secret}, "签名密钥已轮换")
+}
+
+// TestSubscription 发送测试事件
+// @Summary 发送测试事件
+// @Description 向订阅发送一个 webhook.ping 事件，可在投递记录中查看结果（仅管理员）
+// @Tags Webhook订阅
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "订阅ID"
+// @Success 200 {object} Response "投递记录"
+// @Router /api/v1/admin/webhooks/{id}/test [post]
+func (c *WebhookController) TestSubscription(ctx *gin.Context) {
+	id, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	delivery, err := c.webhookService.SendTestEvent(id)
+	if err != nil {
+		c.webhookError(ctx, err)
+		return
+	}
+	c.Success(ctx, delivery, "测试事件已加入发送队列")
+}
+
+// GetDeliveries 获取订阅的投递记录
+// @Summary 获取订阅的投递记录
+// @Description 分页查询投递记录，包含响应状态码、响应内容、耗时和错误，支持 filter[status][eq]=failed 等筛选（仅管理员）
+// @Tags Webhook订阅
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "订阅ID"
+// @Param filter[status][eq] query string false "按状态筛选(pending/delivering/retrying/succeeded/failed)"
+// @Param sort query string false "排序字段，逗号分隔，前缀-表示降序" default(-created_at)
+// @Param page query int false "页码" default(1)
+// @Param limit query int false "每页数量" default(20)
+// @Success 200 {object} Response "投递记录"
+// @Router /api/v1/admin/webhooks/{id}/deliveries [get]
+func (c *WebhookController) GetDeliveries(ctx *gin.Context) {
+	id, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	req, err := c.ParsePageRequest(ctx)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	q, err := Utils.ParseListQuery(ctx.Request.URL.Query(), WebhookDeliveryQuerySpec)
+	if err != nil {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	deliveries, meta, err := c.webhookService.ListDeliveries(id, q, req)
+	if errors.Is(err, Utils.ErrInvalidCursor) || errors.Is(err, Utils.ErrInvalidQuery) {
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		c.webhookError(ctx, err)
+		return
+	}
+
+	data, err := q.Project(deliveries)
+	if err != nil {
+		c.Error(ctx, http.StatusInternalServerError, "获取投递记录失败: "+err.Error())
+		return
+	}
+	c.ListSuccess(ctx, data, meta, "投递记录获取成功")
+}
+
+// GetDelivery 获取投递记录详情
+// @Summary 获取投递记录详情
+// @Tags Webhook订阅
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "订阅ID"
+// @Param delivery_id path int true "投递记录ID"
+// @Success 200 {object} Response "投递记录"
+// @Failure 404 {object} Response "投递记录不存在"
+// @Router /api/v1/admin/webhooks/{id}/deliveries/{delivery_id} [get]
+func (c *WebhookController) GetDelivery(ctx *gin.Context) {
+	id, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := c.pathID(ctx, "delivery_id")
+	if !ok {
+		return
+	}
+	delivery, err := c.webhookService.GetDelivery(id, deliveryID)
+	if err != nil {
+		c.webhookError(ctx, err)
+		return
+	}
+	c.Success(ctx, delivery, "投递记录获取成功")
+}
+
+// RetryDelivery 重试发送失败的投递
+// @Summary 重试发送失败的投递
+// @Description 重置发送次数并重新发送，请求体和投递ID不变（仅管理员）
+// @Tags Webhook订阅
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "订阅ID"
+// @Param delivery_id path int true "投递记录ID"
+// @Success 200 {object} Response "投递记录"
+// @Failure 409 {object} Response "投递记录不是失败状态"
+// @Router /api/v1/admin/webhooks/{id}/deliveries/{delivery_id}/retry [post]
+func (c *WebhookController) RetryDelivery(ctx *gin.Context) {
+	id, ok := c.pathID(ctx, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := c.pathID(ctx, "delivery_id")
+	if !ok {
+		return
+	}
+	delivery, err := c.webhookService.RetryDelivery(id, deliveryID)
+	if err != nil {
+		c.webhookError(ctx, err)
+		return
+	}
+	c.Success(ctx, delivery, "投递已重新加入发送队列")
+}
+
+// pathID 解析路径中的ID
+func (c *WebhookController) pathID(ctx *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param(name), 10, 32)
+	if err != nil || id == 0 {
+		c.Error(ctx, http.StatusBadRequest, "无效的ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// webhookError 按错误类型返回状态码
+func (c *WebhookController) webhookError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, Services.ErrWebhookSubscriptionNotFound), errors.Is(err, Services.ErrWebhookDeliveryNotFound):
+		c.Error(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, Services.ErrInvalidWebhookSubscription), errors.Is(err, Services.ErrWebhookPrivateAddress):
+		c.Error(ctx, http.StatusBadRequest, err.Error())
+	case errors.Is(err, Services.ErrWebhookDeliveryNotRetryable):
+		c.Error(ctx, http.StatusConflict, err.Error())
+	default:
+		c.Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -1,6 +1,7 @@
 package Middleware
 
 import (
+	"cloud-platform-api/app/Services"
 	"cloud-platform-api/app/Storage"
 	"crypto/hmac"
 	"crypto/sha256"
@@ -120,6 +121,11 @@ func (m *APIKeyMiddleware) Handle() gin.HandlerFunc {
 			return
 		}
 
+		// 统计API调用用量，计入密钥所属用户
+		if metering := Services.DefaultMeteringService(); metering != nil && !NewMeteringMiddleware(metering).Check(c, keyInfo.UserID) {
+			return
+		}
+
 		// 更新API密钥使用信息
 		m.updateAPIKeyUsage(keyInfo)
 
@@ -0,0 +1,136 @@
+package Middleware
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Utils"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// ApprovalIDHeader 执行已通过审批的操作时携带的审批ID
+	ApprovalIDHeader = "X-Approval-ID"
+	// ApprovalReasonHeader 发起需要审批的操作时填写的原因
+	ApprovalReasonHeader = "X-Approval-Reason"
+)
+
+// ApprovalMiddleware 敏感操作双人审批中间件
+type ApprovalMiddleware struct {
+	BaseMiddleware
+	service *Services.ApprovalService
+}
+
+// NewApprovalMiddleware 创建双人审批中间件
+// 功能说明：
+// 1. 未携带审批ID的请求不执行，创建待审批记录并返回202，响应中包含审批记录
+// 2. 另一名管理员审批通过后，发起人携带 X-Approval-ID 重新提交相同的请求才会执行
+// 3. service 为 nil 时使用全局审批服务，未设置或操作不需要审批时直接执行
+func NewApprovalMiddleware(service *Services.ApprovalService) *ApprovalMiddleware {
+	return &ApprovalMiddleware{service: service}
+}
+
+// Require 要求操作经过双人审批，需要在认证之后使用
+func (m *ApprovalMiddleware) Require(action string) gin.HandlerFunc {
+	return m.RequireWhen(action, nil)
+}
+
+// RequireWhen 满足条件时要求操作经过双人审批，applies 为 nil 时总是需要审批
+//
+// applies 读取的是请求体的副本；检查条件失败时按需要审批处理。
+func (m *ApprovalMiddleware) RequireWhen(action string, applies func(c *gin.Context, body []byte) (bool, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		service := m.service
+		if service == nil {
+			service = Services.DefaultApprovalService()
+		}
+		if service == nil || !service.Requires(action) {
+			c.Next()
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			if body, err = io.ReadAll(c.Request.Body); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "读取请求体失败"})
+				c.Abort()
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		if applies != nil {
+			required, err := applies(c, body)
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+			if err != nil {
+				log.Printf("检查操作 %s 是否需要审批失败，按需要审批处理: %v", action, err)
+			} else if !required {
+				c.Next()
+				return
+			}
+		}
+
+		userID, _ := strconv.ParseUint(c.GetString("user_id"), 10, 64)
+		req := Services.ApprovalRequest{
+			Action:        action,
+			Method:        c.Request.Method,
+			Path:          c.Request.URL.RequestURI(),
+			Payload:       body,
+			Reason:        strings.TrimSpace(c.GetHeader(ApprovalReasonHeader)),
+			RequestedBy:   uint(userID),
+			RequesterName: c.GetString("username"),
+			IPAddress:     c.ClientIP(),
+			RequestID:     c.GetString(Utils.RequestIDKey),
+		}
+
+		header := strings.TrimSpace(c.GetHeader(ApprovalIDHeader))
+		if header == "" {
+			approval, err := service.Request(req)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+				c.Abort()
+				return
+			}
+			c.JSON(http.StatusAccepted, gin.H{
+				"success": true,
+				"code":    "APPROVAL_REQUIRED",
+				"message": "该操作需要另一名管理员审批，审批通过后携带 " + ApprovalIDHeader + " 请求头重新提交",
+				"data":    approval,
+			})
+			c.Abort()
+			return
+		}
+
+		id, err := strconv.ParseUint(header, 10, 64)
+		if err != nil || id == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "审批ID无效"})
+			c.Abort()
+			return
+		}
+		approval, err := service.Claim(uint(id), req)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, Services.ErrApprovalNotFound):
+				status = http.StatusNotFound
+			case errors.Is(err, Services.ErrApprovalMismatch):
+				status = http.StatusForbidden
+			case errors.Is(err, Services.ErrApprovalNotApproved), errors.Is(err, Services.ErrApprovalExpired):
+				status = http.StatusConflict
+			}
+			c.JSON(status, gin.H{"success": false, "message": err.Error()})
+			c.Abort()
+			return
+		}
+
+		c.Set("approval_id", approval.ID)
+		c.Next()
+		service.Complete(approval, c.Writer.Status(), req)
+	}
+}
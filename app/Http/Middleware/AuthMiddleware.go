@@ -2,6 +2,8 @@ package Middleware
 
 import (
 	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/I18n"
 	"cloud-platform-api/app/Services"
 	"cloud-platform-api/app/Storage"
 	"cloud-platform-api/app/Utils"
@@ -13,11 +15,24 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// 需要重新进行MFA验证时仍允许访问的接口
+const mfaReverificationPathPrefix = "/api/v1/auth/mfa/"
+
+var mfaReverificationAllowedPaths = map[string]bool{
+	"/api/v1/auth/logout": true,
+}
+
+// mfaReverificationAllowed 需要重新进行MFA验证的用户是否允许访问该路径
+func mfaReverificationAllowed(path string) bool {
+	return strings.HasPrefix(path, mfaReverificationPathPrefix) || mfaReverificationAllowedPaths[path]
+}
+
 // AuthMiddleware 认证中间件
 type AuthMiddleware struct {
 	BaseMiddleware
 	tokenBlacklistService *Services.TokenBlacklistService
 	storageManager        *Storage.StorageManager
+	passwordExpiry        *PasswordExpiryMiddleware
 }
 
 // NewAuthMiddleware 创建认证中间件
@@ -54,9 +69,16 @@ func NewAuthMiddleware() *AuthMiddleware {
 	}
 	storageManager := Storage.NewStorageManager(storageConfig)
 
+	// 启用强制修改密码时检查密码是否过期
+	var passwordExpiry *PasswordExpiryMiddleware
+	if Config.GetConfig().Security.BaseSecurity.ForcePasswordChange && Database.GetDB() != nil {
+		passwordExpiry = NewPasswordExpiryMiddleware(Services.NewPasswordExpiryService(Database.GetDB(), nil, nil))
+	}
+
 	return &AuthMiddleware{
 		tokenBlacklistService: tokenBlacklistService,
 		storageManager:        storageManager,
+		passwordExpiry:        passwordExpiry,
 	}
 }
 
@@ -100,6 +122,7 @@ func NewAuthMiddleware() *AuthMiddleware {
 // 3. 检查token是否在黑名单中（已撤销）
 // 4. 解析token获取用户信息
 // 5. 将用户信息存储到上下文中，供后续中间件和处理器使用
+// 6. Authorization 为 "HMAC-SHA256 ..." 时使用请求签名认证（服务间调用），见 RequestSigningMiddleware
 //
 // 认证流程：
 // 1. 检查Authorization请求头是否存在
@@ -144,6 +167,23 @@ func (m *AuthMiddleware) Handle() gin.HandlerFunc {
 			return
 		}
 
+		// 服务间调用使用请求签名认证，上下文与JWT认证相同
+		if strings.HasPrefix(token, Services.SigningAuthScheme+" ") {
+			signing := Services.DefaultRequestSigningService()
+			if signing == nil {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"success": false,
+					"message": "Request signing is not enabled",
+				})
+				c.Abort()
+				return
+			}
+			if NewRequestSigningMiddleware(signing).Authenticate(c) {
+				c.Next()
+			}
+			return
+		}
+
 		// 检查token格式
 		// 必须是"Bearer "开头，且长度至少为7（"Bearer "的长度）
 		if len(token) < 7 || !strings.HasPrefix(token, "Bearer ") {
@@ -187,12 +227,55 @@ func (m *AuthMiddleware) Handle() gin.HandlerFunc {
 			return
 		}
 
+		// 检查用户是否被强制下线（自动响应撤销了该用户此前签发的所有token）
+		if m.tokenBlacklistService != nil && claims.IssuedAt != nil &&
+			m.tokenBlacklistService.IsUserTokenRevoked(claims.UserID, claims.IssuedAt.Time) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"message": "Token has been revoked",
+			})
+			c.Abort() // 停止后续处理
+			return
+		}
+
+		// 需要重新进行MFA验证时通过上下文和响应头告知后续处理器和客户端，完成验证前只能访问MFA验证和登出接口
+		if m.tokenBlacklistService != nil && m.tokenBlacklistService.IsMFAReverificationRequired(claims.UserID) {
+			c.Set("mfa_reverification_required", true)
+			c.Header("X-MFA-Reverification-Required", "true")
+			if !mfaReverificationAllowed(c.Request.URL.Path) {
+				c.JSON(http.StatusForbidden, gin.H{
+					"success": false,
+					"message": I18n.TContext(c.Request.Context(), "auth.mfa_reverification_required"),
+					"code":    "MFA_REVERIFICATION_REQUIRED",
+				})
+				c.Abort()
+				return
+			}
+		}
+
 		// 将用户信息存储到上下文中，供后续中间件和处理器使用
 		// 注意：统一使用string类型存储user_id，确保类型一致性
 		// 后续代码可以通过c.GetString("user_id")获取用户ID
 		c.Set("user_id", fmt.Sprintf("%d", claims.UserID)) // 用户ID（string类型）
 		c.Set("username", claims.Username)                   // 用户名
 		c.Set("user_role", claims.Role)                      // 用户角色
+		Services.ErrorScopeFromContext(c).SetUser(fmt.Sprintf("%d", claims.UserID))
+
+		// 统计API调用用量，超过本周期限额时返回429（模拟登录的请求计入被模拟用户）
+		if metering := Services.DefaultMeteringService(); metering != nil && !NewMeteringMiddleware(metering).Check(c, claims.UserID) {
+			return
+		}
+
+		// 管理员模拟登录的令牌需要校验模拟会话，并为每个请求记录审计日志
+		if claims.IsImpersonation() {
+			m.handleImpersonation(c, claims)
+			return
+		}
+
+		// 必须修改密码的用户只能访问修改密码和登出接口
+		if m.passwordExpiry != nil && !m.passwordExpiry.Check(c) {
+			return
+		}
 
 		// 记录认证成功日志（可选）
 		// 这里可以集成日志服务来记录认证成功事件
@@ -210,6 +293,62 @@ func (m *AuthMiddleware) Handle() gin.HandlerFunc {
 	}
 }
 
+// handleImpersonation 处理模拟令牌的请求
+// 功能说明：
+// 1. 模拟会话已结束或已过期时返回401，令牌立即失效
+// 2. 上下文记录 impersonator_id、impersonator、impersonation_id，响应头 X-Impersonated-By 标明模拟的管理员
+// 3. 敏感操作和只读会话的写操作返回403，被拒绝的请求同样记录审计日志
+// 4. 模拟期间不检查被模拟用户的密码是否过期，避免管理员被引导修改用户密码
+func (m *AuthMiddleware) handleImpersonation(c *gin.Context, claims *Utils.Claims) {
+	service := Services.DefaultImpersonationService(Database.GetDB())
+	if service == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "Impersonation session unavailable",
+		})
+		c.Abort()
+		return
+	}
+	session, err := service.ActiveSession(claims.ImpersonationID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "Impersonation session has ended",
+		})
+		c.Abort()
+		return
+	}
+
+	c.Set("impersonator_id", fmt.Sprintf("%d", session.ImpersonatorID))
+	c.Set("impersonator", session.ImpersonatorName)
+	c.Set("impersonation_id", session.SessionID)
+	c.Header("X-Impersonated-By", session.ImpersonatorName)
+
+	route := c.FullPath()
+	if route == "" {
+		route = c.Request.URL.Path
+	}
+	if err := service.Authorize(session, c.Request.Method, route); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		c.Abort()
+	} else {
+		c.Next()
+	}
+
+	service.RecordRequest(session, Services.ImpersonatedRequest{
+		Method:        c.Request.Method,
+		Path:          c.Request.URL.Path,
+		Status:        c.Writer.Status(),
+		IPAddress:     c.ClientIP(),
+		UserAgent:     c.Request.UserAgent(),
+		RequestID:     c.GetString(Utils.RequestIDKey),
+		CorrelationID: c.GetString(Utils.CorrelationIDKey),
+	})
+}
+
 // AdminMiddleware 管理员中间件
 type AdminMiddleware struct {
 	BaseMiddleware
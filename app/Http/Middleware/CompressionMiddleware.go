@@ -0,0 +1,356 @@
+package Middleware
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+// compressor gzip.Writer 和 brotli.Writer 的公共方法
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// CompressionMiddleware 响应压缩中间件
+type CompressionMiddleware struct {
+	BaseMiddleware
+	config       *Config.CompressionConfig
+	algorithms   []string
+	contentTypes []string
+	excludePaths []string
+	pools        map[string]*sync.Pool
+}
+
+// NewCompressionMiddleware 创建响应压缩中间件
+// 功能说明：
+// 1. 按 Accept-Encoding 协商 br 或 gzip，压缩器通过对象池复用
+// 2. 响应体先缓存到 MinSize 再决定是否压缩，不足 MinSize 的响应原样写出
+// 3. 处理器刷新时立即开始压缩并把已压缩的数据写出，流式响应不会被缓存
+// 4. 已设置 Content-Encoding、状态码不允许响应体或内容类型不在允许列表中的响应不压缩
+// 5. 配置加载时已验证，config 为空时使用默认配置
+func NewCompressionMiddleware(config *Config.CompressionConfig) *CompressionMiddleware {
+	if config == nil {
+		config = &Config.CompressionConfig{
+			Enabled:      true,
+			Algorithms:   "br,gzip",
+			MinSize:      1024,
+			ContentTypes: "application/json,text/plain,text/html",
+			GzipLevel:    gzip.DefaultCompression,
+			BrotliLevel:  brotli.DefaultCompression,
+		}
+	}
+	m := &CompressionMiddleware{
+		config:       config,
+		contentTypes: config.ContentTypeList(),
+		excludePaths: config.ExcludePathList(),
+	}
+	m.algorithms, _ = config.AlgorithmList()
+	m.pools = map[string]*sync.Pool{
+		Config.CompressionGzip: {New: func() interface{} {
+			writer, err := gzip.NewWriterLevel(io.Discard, config.GzipLevel)
+			if err != nil {
+				writer = gzip.NewWriter(io.Discard)
+			}
+			return writer
+		}},
+		Config.CompressionBrotli: {New: func() interface{} {
+			return brotli.NewWriterLevel(io.Discard, config.BrotliLevel)
+		}},
+	}
+	return m
+}
+
+// Handle 处理响应压缩
+func (m *CompressionMiddleware) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.config.Enabled || !m.eligibleRequest(c.Request) {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"), m.algorithms)
+		if encoding == "" {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		writer := &compressWriter{
+			ResponseWriter: original,
+			middleware:     m,
+			encoding:       encoding,
+			status:         http.StatusOK,
+		}
+		c.Writer = writer
+		defer func() {
+			writer.finish()
+			c.Writer = original
+		}()
+		c.Next()
+	}
+}
+
+// eligibleRequest WebSocket升级、Range和HEAD请求以及排除的路由分组不压缩
+func (m *CompressionMiddleware) eligibleRequest(req *http.Request) bool {
+	if req.Method == http.MethodHead || req.Header.Get("Range") != "" {
+		return false
+	}
+	if strings.EqualFold(req.Header.Get("Upgrade"), "websocket") {
+		return false
+	}
+	path := req.URL.Path
+	for _, prefix := range m.excludePaths {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return false
+		}
+	}
+	return true
+}
+
+// compressible 内容类型是否在允许列表中，事件流只匹配显式列出的 text/event-stream
+func (m *CompressionMiddleware) compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range m.contentTypes {
+		if allowed == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && mediaType != "text/event-stream" && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateEncoding 按 Accept-Encoding 的权重选择算法，权重相同时按服务端顺序，q=0 表示不接受
+func negotiateEncoding(header string, algorithms []string) string {
+	if header == "" {
+		return ""
+	}
+	weights := make(map[string]float64)
+	wildcard := -1.0
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		if name == "*" {
+			wildcard = q
+			continue
+		}
+		weights[name] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, algorithm := range algorithms {
+		q, ok := weights[algorithm]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = algorithm, q
+		}
+	}
+	return best
+}
+
+// 压缩状态
+const (
+	compressPending     = iota // 缓存响应体，尚未决定是否压缩
+	compressActive             // 正在压缩
+	compressPassthrough        // 不压缩，直接写出
+)
+
+// compressWriter 压缩响应体的写入器
+type compressWriter struct {
+	gin.ResponseWriter
+	middleware *CompressionMiddleware
+	encoding   string
+	status     int
+	state      int
+	size       int
+	buffer     bytes.Buffer
+	compressor compressor
+}
+
+// WriteHeader 记录状态码，决定是否压缩后再写出
+func (w *compressWriter) WriteHeader(code int) {
+	if code <= 0 {
+		// SSEvent 等渲染方法使用 -1 表示不修改状态码
+		return
+	}
+	w.status = code
+	if w.state == compressPassthrough {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+// WriteHeaderNow 处理器要求立即写出响应头时不再压缩
+func (w *compressWriter) WriteHeaderNow() {
+	if w.state == compressPending {
+		w.passthrough()
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	w.size += len(data)
+	switch w.state {
+	case compressActive:
+		return w.compressor.Write(data)
+	case compressPassthrough:
+		return w.ResponseWriter.Write(data)
+	}
+
+	if !w.eligible(data) {
+		w.passthrough()
+		if w.buffer.Len() > 0 {
+			if _, err := w.ResponseWriter.Write(w.buffer.Bytes()); err != nil {
+				return 0, err
+			}
+			w.buffer.Reset()
+		}
+		return w.ResponseWriter.Write(data)
+	}
+	w.buffer.Write(data)
+	if w.buffer.Len() >= w.middleware.config.MinSize {
+		if err := w.start(); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	if w.state == compressPassthrough {
+		w.size += len(s)
+		return w.ResponseWriter.WriteString(s)
+	}
+	return w.Write([]byte(s))
+}
+
+// Flush 流式响应刷新时立即开始压缩，把压缩器中的数据写出
+func (w *compressWriter) Flush() {
+	if w.state == compressPending {
+		if w.eligible(nil) {
+			w.start()
+		} else {
+			w.passthrough()
+			if w.buffer.Len() > 0 {
+				w.ResponseWriter.Write(w.buffer.Bytes())
+				w.buffer.Reset()
+			}
+		}
+	}
+	if w.state == compressActive {
+		w.compressor.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Status 返回记录的状态码
+func (w *compressWriter) Status() int {
+	if w.state == compressPassthrough {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+// Size 返回处理器写入的未压缩字节数
+func (w *compressWriter) Size() int {
+	if w.size == 0 && w.state == compressPending {
+		return w.ResponseWriter.Size()
+	}
+	return w.size
+}
+
+// Written 缓存了响应体时视为已写出
+func (w *compressWriter) Written() bool {
+	return w.state != compressPending || w.buffer.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// eligible 响应是否可以压缩，data 为首次写入的数据，未设置 Content-Type 时用于识别类型
+func (w *compressWriter) eligible(data []byte) bool {
+	if w.status < http.StatusOK || w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		return false
+	}
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	if length, err := strconv.Atoi(header.Get("Content-Length")); err == nil && length < w.middleware.config.MinSize {
+		return false
+	}
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		if len(data) == 0 && w.buffer.Len() == 0 {
+			return false
+		}
+		contentType = http.DetectContentType(append(w.buffer.Bytes(), data...))
+		header.Set("Content-Type", contentType)
+	}
+	return w.middleware.compressible(contentType)
+}
+
+// start 开始压缩，写出响应头和已缓存的响应体
+func (w *compressWriter) start() error {
+	header := w.Header()
+	header.Set("Content-Encoding", w.encoding)
+	header.Del("Content-Length")
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		header.Set("ETag", "W/"+etag)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	w.compressor = w.middleware.pools[w.encoding].Get().(compressor)
+	w.compressor.Reset(w.ResponseWriter)
+	w.state = compressActive
+	if w.buffer.Len() > 0 {
+		if _, err := w.compressor.Write(w.buffer.Bytes()); err != nil {
+			return err
+		}
+		w.buffer.Reset()
+	}
+	return nil
+}
+
+// passthrough 不压缩，写出记录的状态码
+func (w *compressWriter) passthrough() {
+	w.state = compressPassthrough
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+// finish 请求结束时写出不足 MinSize 的响应体或结束压缩流
+func (w *compressWriter) finish() {
+	switch w.state {
+	case compressPending:
+		w.passthrough()
+		if w.buffer.Len() > 0 {
+			w.ResponseWriter.Write(w.buffer.Bytes())
+			w.buffer.Reset()
+		}
+	case compressActive:
+		if err := w.compressor.Close(); err != nil {
+			w.middleware.LogWarning("响应压缩失败", map[string]interface{}{"encoding": w.encoding, "error": err.Error()})
+		}
+		w.compressor.Reset(io.Discard)
+		w.middleware.pools[w.encoding].Put(w.compressor)
+		w.compressor = nil
+	}
+}
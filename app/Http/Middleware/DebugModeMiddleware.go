@@ -0,0 +1,113 @@
+package Middleware
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DebugIDHeader 响应头，值为调试记录的请求ID
+const DebugIDHeader = "X-Debug-Id"
+
+// DebugModeMiddleware 请求调试模式中间件
+type DebugModeMiddleware struct {
+	BaseMiddleware
+	store       *Services.DebugRequestStore
+	header      string
+	maxEntries  int
+	permissions *PermissionMiddleware
+}
+
+// NewDebugModeMiddleware 创建请求调试模式中间件
+func NewDebugModeMiddleware(store *Services.DebugRequestStore, config *Config.DebugModeConfig) *DebugModeMiddleware {
+	return &DebugModeMiddleware{
+		store:       store,
+		header:      config.Header,
+		maxEntries:  config.MaxEntries,
+		permissions: &PermissionMiddleware{},
+	}
+}
+
+// Handle 记录携带调试请求头的请求执行的SQL、缓存操作和出站HTTP调用
+// 功能说明：
+// 1. 请求头 DEBUG_MODE_HEADER 的值为 1 或 true 时创建调试记录器，放入 gin.Context 和请求上下文
+// 2. 认证由路由分组的中间件完成，请求结束后确认当前用户是管理员才保存记录，其他用户的记录直接丢弃
+// 3. 写出响应头时当前用户是管理员则设置 X-Debug-Id，通过 /api/v1/admin/debug/requests/{request_id} 查看记录
+//
+// 注意事项：
+// - 需要在请求ID中间件之后执行，记录以请求ID为键
+// - 管理员角色按当前角色判断（启用模型缓存时从缓存读取），与 RequireRole 一致
+func (m *DebugModeMiddleware) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetString(Utils.RequestIDKey)
+		if enabled, _ := strconv.ParseBool(c.GetHeader(m.header)); !enabled || requestID == "" {
+			c.Next()
+			return
+		}
+
+		recorder := Services.NewDebugRecorder(requestID, c.Request.Method, c.Request.URL.Path, m.maxEntries)
+		c.Set(Services.DebugRecorderKey, recorder)
+		c.Request = c.Request.WithContext(Services.WithDebugRecorder(c.Request.Context(), recorder))
+		writer := &debugWriter{ResponseWriter: c.Writer, middleware: m, c: c, requestID: requestID}
+		c.Writer = writer
+
+		c.Next()
+
+		if !m.isAdmin(c) {
+			return
+		}
+		m.store.Save(recorder.Finish(c.Writer.Status(), c.FullPath(), c.GetString("user_id")))
+	}
+}
+
+// isAdmin 当前用户是否是管理员，未认证时返回 false
+func (m *DebugModeMiddleware) isAdmin(c *gin.Context) bool {
+	role := c.GetString("user_role")
+	if role == "" {
+		return false
+	}
+	return m.permissions.resolveRole(c.GetString("user_id"), role) == "admin"
+}
+
+// debugWriter 写出响应头前为管理员设置 X-Debug-Id
+type debugWriter struct {
+	gin.ResponseWriter
+	middleware *DebugModeMiddleware
+	c          *gin.Context
+	requestID  string
+	checked    bool
+}
+
+// setHeader 响应头写出前检查一次当前用户
+func (w *debugWriter) setHeader() {
+	if w.checked || w.ResponseWriter.Written() {
+		return
+	}
+	w.checked = true
+	if w.middleware.isAdmin(w.c) {
+		w.Header().Set(DebugIDHeader, w.requestID)
+	}
+}
+
+func (w *debugWriter) WriteHeaderNow() {
+	w.setHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *debugWriter) Write(data []byte) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *debugWriter) WriteString(s string) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *debugWriter) Flush() {
+	w.setHeader()
+	w.ResponseWriter.Flush()
+}
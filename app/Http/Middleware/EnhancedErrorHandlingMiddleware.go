@@ -59,7 +59,8 @@ func NewEnhancedErrorHandlingMiddleware(config *EnhancedErrorHandlingConfig) *En
 func (m *EnhancedErrorHandlingMiddleware) Handle() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 生成请求ID
-		if m.config.EnableRequestID {
+		// 请求ID中间件已设置时沿用，保证日志和响应中的请求ID一致
+		if m.config.EnableRequestID && c.GetString("request_id") == "" {
 			requestID := m.generateRequestID()
 			c.Set("request_id", requestID)
 			c.Header("X-Request-ID", requestID)
@@ -0,0 +1,63 @@
+package Middleware
+
+import (
+	"cloud-platform-api/app/Services"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FaultInjectionMiddleware 路由故障注入中间件
+type FaultInjectionMiddleware struct {
+	BaseMiddleware
+	injector *Services.FaultInjector
+}
+
+// NewFaultInjectionMiddleware 创建路由故障注入中间件
+func NewFaultInjectionMiddleware(injector *Services.FaultInjector) *FaultInjectionMiddleware {
+	return &FaultInjectionMiddleware{injector: injector}
+}
+
+// Handle 执行请求触发的路由故障
+// 功能说明：
+// 1. 按请求方法和路径（或注册的路由模板）匹配生效中的路由故障
+// 2. 先等待注入的延迟，再对 error 类型的故障返回指定状态码，响应头 X-Fault-Injection 为触发的注入ID
+// 3. 故障注入管理接口不受影响，注入后总能撤销
+//
+// 注意事项：
+// - 作为全局中间件放在监控和统计中间件之后，注入的延迟和错误会计入请求指标，用于验证告警
+func (m *FaultInjectionMiddleware) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if strings.HasPrefix(c.Request.URL.Path, Services.FaultInjectionAdminPath) {
+			c.Next()
+			return
+		}
+		faults := m.injector.RouteFaults(c.Request.Method, c.Request.URL.Path, c.FullPath())
+		for _, fault := range faults {
+			if fault.LatencyMs == 0 {
+				continue
+			}
+			timer := time.NewTimer(fault.Latency())
+			select {
+			case <-timer.C:
+			case <-c.Request.Context().Done():
+				timer.Stop()
+				c.Abort()
+				return
+			}
+		}
+		for _, fault := range faults {
+			if fault.Type == Services.FaultError {
+				c.Header("X-Fault-Injection", fault.ID)
+				c.AbortWithStatusJSON(fault.StatusCode, gin.H{
+					"success": false,
+					"message": fault.Message,
+					"error":   "fault_injected",
+				})
+				return
+			}
+		}
+		c.Next()
+	}
+}
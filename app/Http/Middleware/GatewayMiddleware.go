@@ -0,0 +1,389 @@
+package Middleware
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gin-gonic/gin/render"
+)
+
+// GatewayMiddleware 网关策略中间件
+type GatewayMiddleware struct {
+	BaseMiddleware
+	config      *Config.GatewayConfig
+	headerRules []Config.GatewayHeaderRule
+	redactRules []Config.GatewayRedactRule
+	bodyLimits  []Config.GatewayBodyLimit
+	maxBodySize int64
+}
+
+// NewGatewayMiddleware 创建网关策略中间件
+// 功能说明：
+// 1. 请求进入时按路由移除和注入请求头，检查请求体大小，MessagePack 请求体转换为JSON
+// 2. 需要脱敏或返回 MessagePack 时缓存JSON响应，处理完成后脱敏字段、转换格式再写出
+// 3. 事件流等非JSON响应不缓存，直接写出
+// 4. 配置加载时已验证，解析失败的策略不生效
+func NewGatewayMiddleware(config *Config.GatewayConfig) *GatewayMiddleware {
+	if config == nil {
+		config = &Config.GatewayConfig{}
+	}
+	m := &GatewayMiddleware{config: config}
+	m.headerRules, _ = config.ParseRequestHeaders()
+	m.redactRules, _ = config.ParseRedactFields()
+	m.bodyLimits, _ = config.ParseBodyLimits()
+	m.maxBodySize, _ = Config.ParseByteSize(config.MaxBodySize)
+	return m
+}
+
+// Handle 处理网关策略
+func (m *GatewayMiddleware) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.config.Enabled {
+			c.Next()
+			return
+		}
+		method, fullPath, path := c.Request.Method, c.FullPath(), c.Request.URL.Path
+
+		for _, rule := range m.headerRules {
+			if !rule.Scope.Matches(method, fullPath, path) {
+				continue
+			}
+			for _, name := range rule.Remove {
+				c.Request.Header.Del(name)
+			}
+			for _, header := range rule.Set {
+				c.Request.Header.Set(header.Name, header.Value)
+			}
+		}
+
+		if limit := m.bodyLimit(method, fullPath, path); limit > 0 && c.Request.Body != nil {
+			if c.Request.ContentLength > limit {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+					"success": false,
+					"message": fmt.Sprintf("请求体大小超过限制 %d 字节", limit),
+				})
+				return
+			}
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		}
+
+		if m.config.MsgpackEnabled && isMsgpack(c.ContentType()) {
+			if err := msgpackBodyToJSON(c.Request); err != nil {
+				status := http.StatusBadRequest
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					status = http.StatusRequestEntityTooLarge
+				}
+				c.AbortWithStatusJSON(status, gin.H{"success": false, "message": "MessagePack 请求体无效: " + err.Error()})
+				return
+			}
+		}
+
+		useMsgpack := false
+		if m.config.MsgpackEnabled {
+			c.Writer.Header().Add("Vary", "Accept")
+			format := c.NegotiateFormat(binding.MIMEJSON, binding.MIMEMSGPACK2, binding.MIMEMSGPACK)
+			useMsgpack = format == binding.MIMEMSGPACK || format == binding.MIMEMSGPACK2
+		}
+		if !useMsgpack && !m.mayRedact(method, fullPath, path) {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		writer := &gatewayWriter{ResponseWriter: original, status: http.StatusOK}
+		c.Writer = writer
+		defer func() { c.Writer = original }()
+		c.Next()
+		if !writer.decided {
+			// 处理器只设置了状态码，没有写出响应体
+			original.WriteHeader(writer.status)
+			return
+		}
+		if !writer.buffering {
+			return
+		}
+
+		body := writer.body.Bytes()
+		if fields := m.redactFields(c.GetString("user_role"), method, fullPath, path); len(fields) > 0 {
+			body = redactJSON(body, fields)
+		}
+		header := original.Header()
+		header.Del("Content-Length")
+		if useMsgpack {
+			if data, err := decodeJSONForMsgpack(body); err == nil {
+				header.Del("Content-Type")
+				original.WriteHeader(writer.status)
+				if err := (render.MsgPack{Data: data}).Render(original); err != nil {
+					m.LogWarning("MessagePack 响应编码失败", map[string]interface{}{"path": path, "error": err.Error()})
+				}
+				return
+			}
+		}
+		original.WriteHeader(writer.status)
+		original.Write(body)
+	}
+}
+
+// bodyLimit 路由的请求体大小限制，未匹配时使用默认限制
+func (m *GatewayMiddleware) bodyLimit(method, fullPath, path string) int64 {
+	for _, limit := range m.bodyLimits {
+		if limit.Scope.Matches(method, fullPath, path) {
+			return limit.Limit
+		}
+	}
+	return m.maxBodySize
+}
+
+// mayRedact 路由是否配置了脱敏规则，请求进入时还不知道用户角色，只按路由判断是否需要缓存响应
+func (m *GatewayMiddleware) mayRedact(method, fullPath, path string) bool {
+	for _, rule := range m.redactRules {
+		if rule.Scope == nil || rule.Scope.Matches(method, fullPath, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactFields 角色在路由上需要脱敏的字段
+func (m *GatewayMiddleware) redactFields(role, method, fullPath, path string) map[string]bool {
+	fields := make(map[string]bool)
+	for _, rule := range m.redactRules {
+		if rule.Role != "*" && rule.Role != role {
+			continue
+		}
+		if rule.Scope != nil && !rule.Scope.Matches(method, fullPath, path) {
+			continue
+		}
+		for _, field := range rule.Fields {
+			fields[field] = true
+		}
+	}
+	return fields
+}
+
+// gatewayWriter 缓存JSON响应，其他类型的响应直接写出
+type gatewayWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	status    int
+	decided   bool
+	buffering bool
+}
+
+// WriteHeader 记录状态码，写出响应体时再决定是否缓存
+func (w *gatewayWriter) WriteHeader(code int) {
+	if code <= 0 {
+		return
+	}
+	if w.decided && !w.buffering {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+}
+
+// WriteHeaderNow 缓存时不写出响应头
+func (w *gatewayWriter) WriteHeaderNow() {
+	w.decide()
+	if !w.buffering {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *gatewayWriter) Write(data []byte) (int, error) {
+	w.decide()
+	if w.buffering {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *gatewayWriter) WriteString(s string) (int, error) {
+	if w.decided && !w.buffering {
+		return w.ResponseWriter.WriteString(s)
+	}
+	return w.Write([]byte(s))
+}
+
+// Status 缓存时返回记录的状态码
+func (w *gatewayWriter) Status() int {
+	if w.buffering || !w.decided {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+// Size 缓存时返回已缓存的字节数
+func (w *gatewayWriter) Size() int {
+	if w.buffering {
+		return w.body.Len()
+	}
+	return w.ResponseWriter.Size()
+}
+
+// Written 是否已写出响应头
+func (w *gatewayWriter) Written() bool {
+	return w.decided || w.ResponseWriter.Written()
+}
+
+// Flush 缓存时不写出
+func (w *gatewayWriter) Flush() {
+	if !w.buffering {
+		w.ResponseWriter.Flush()
+	}
+}
+
+// decide 首次写出时按 Content-Type 决定是否缓存
+func (w *gatewayWriter) decide() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	w.buffering = strings.Contains(w.Header().Get("Content-Type"), "json")
+	if !w.buffering {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+// redactJSON 替换JSON中任意层级的脱敏字段，响应不是JSON时原样返回
+func redactJSON(body []byte, fields map[string]bool) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return body
+	}
+	if !redactValue(data, fields) {
+		return body
+	}
+	redacted, err := json.Marshal(data)
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+// redactValue 递归替换脱敏字段，返回是否有字段被替换
+func redactValue(value interface{}, fields map[string]bool) bool {
+	changed := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if fields[key] {
+				if item != nil {
+					v[key] = Config.GatewayRedactedValue
+					changed = true
+				}
+				continue
+			}
+			if redactValue(item, fields) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if redactValue(item, fields) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// decodeJSONForMsgpack 解析JSON响应，整数保留为整数
+func decodeJSONForMsgpack(body []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return nil, err
+	}
+	return normalizeJSONNumbers(data), nil
+}
+
+// normalizeJSONNumbers 把 json.Number 转换为 int64 或 float64
+func normalizeJSONNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeJSONNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeJSONNumbers(item)
+		}
+	}
+	return value
+}
+
+// msgpackBodyToJSON 把 MessagePack 请求体转换为JSON，处理器按JSON绑定
+func msgpackBodyToJSON(req *http.Request) error {
+	if req.Body == nil {
+		return nil
+	}
+	raw, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	var data interface{}
+	if len(raw) > 0 {
+		if err := binding.MsgPack.BindBody(raw, &data); err != nil {
+			return err
+		}
+	}
+	body, err := json.Marshal(normalizeMsgpackValue(data))
+	if err != nil {
+		return err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	req.Header.Set("Content-Type", binding.MIMEJSON)
+	return nil
+}
+
+// normalizeMsgpackValue 把 MessagePack 解码得到的 map[interface{}]interface{} 转换为JSON可以编码的类型
+func normalizeMsgpackValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[fmt.Sprint(key)] = normalizeMsgpackValue(item)
+		}
+		return result
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeMsgpackValue(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeMsgpackValue(item)
+		}
+		return v
+	case []byte:
+		return string(v)
+	}
+	return value
+}
+
+// isMsgpack 是否为 MessagePack 内容类型
+func isMsgpack(contentType string) bool {
+	return contentType == binding.MIMEMSGPACK || contentType == binding.MIMEMSGPACK2
+}
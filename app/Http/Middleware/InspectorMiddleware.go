@@ -0,0 +1,109 @@
+package Middleware
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Utils"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InspectorMiddleware 请求检查器中间件
+type InspectorMiddleware struct {
+	BaseMiddleware
+	inspector  *Services.RequestInspector
+	maxEntries int
+}
+
+// NewInspectorMiddleware 创建请求检查器中间件，maxEntries 为单个请求最多记录的操作数
+func NewInspectorMiddleware(inspector *Services.RequestInspector, maxEntries int) *InspectorMiddleware {
+	return &InspectorMiddleware{inspector: inspector, maxEntries: maxEntries}
+}
+
+// Handle 采集请求、响应、异常和请求执行的操作，交给请求检查器异步保存
+// 功能说明：
+// 1. 不记录的路由分组直接跳过；当前环境未采样且没有配置指定用户时不采集
+// 2. 请求体和响应体最多记录 INSPECTOR_MAX_BODY_SIZE 字节，请求体保持完整留给后续处理器
+// 3. 已启用请求调试时复用调试记录器，否则创建新的记录器放入请求上下文，记录SQL、缓存操作、出站调用和通知
+// 4. 处理器 panic 时记录异常和堆栈后继续抛出，由错误恢复中间件返回500；c.Errors 中的错误同样记录为异常
+// 5. 请求结束后按采样结果和当前用户决定是否保存，用户由路由分组的认证中间件确定
+//
+// 注意事项：
+// - 需要在请求ID中间件和错误恢复中间件之后、响应压缩之后执行，记录的是压缩前的响应体
+func (m *InspectorMiddleware) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if m.inspector.Ignored(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		sampled := m.inspector.Sample()
+		if !sampled && !m.inspector.Watching() {
+			c.Next()
+			return
+		}
+
+		recorder, _ := c.Value(Services.DebugRecorderKey).(*Services.DebugRecorder)
+		if recorder == nil {
+			recorder = Services.NewDebugRecorder(c.GetString(Utils.RequestIDKey), c.Request.Method, c.Request.URL.Path, m.maxEntries)
+			c.Set(Services.DebugRecorderKey, recorder)
+			c.Request = c.Request.WithContext(Services.WithDebugRecorder(c.Request.Context(), recorder))
+		}
+
+		record := &Services.InspectorRecord{
+			ClientIP:       c.ClientIP(),
+			RequestHeaders: c.Request.Header.Clone(),
+		}
+		limit := m.inspector.MaxBodySize()
+		if limit > 0 {
+			if body := captureRequestBody(c, limit); body != nil {
+				record.RequestBody = body.data
+				record.RequestTruncated = body.truncated
+			}
+		}
+		writer := &responseBodyWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}, limit: limit}
+		c.Writer = writer
+
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				record.Exception = fmt.Sprintf("panic: %v\n\n%s", recovered, debug.Stack())
+				m.save(c, record, recorder, writer, sampled)
+				panic(recovered)
+			}
+		}()
+
+		c.Next()
+
+		if len(c.Errors) > 0 {
+			messages := make([]string, 0, len(c.Errors))
+			for _, err := range c.Errors {
+				messages = append(messages, err.Error())
+			}
+			record.Exception = strings.Join(messages, "\n")
+		}
+		m.save(c, record, recorder, writer, sampled)
+	}
+}
+
+// save 按采样结果和当前用户决定是否保存记录
+func (m *InspectorMiddleware) save(c *gin.Context, record *Services.InspectorRecord, recorder *Services.DebugRecorder, writer *responseBodyWriter, sampled bool) {
+	userID := c.GetString("user_id")
+	if !m.inspector.ShouldRecord(sampled, userID) {
+		return
+	}
+	status := writer.Status()
+	if record.Exception != "" && !writer.Written() {
+		// panic 时响应尚未写出，由错误恢复中间件返回500
+		status = http.StatusInternalServerError
+	}
+	record.Request = recorder.Finish(status, c.FullPath(), userID)
+	record.ResponseHeaders = writer.Header().Clone()
+	if body := writer.captured(); body != nil {
+		record.ResponseBody = append([]byte(nil), body.data...)
+		record.ResponseTruncated = body.truncated
+	}
+	m.inspector.Record(record)
+}
@@ -114,8 +114,8 @@ func (m *JWTAuthMiddleware) Handle() gin.HandlerFunc {
 		}
 
 		// 验证用户是否存在且状态正常
-		var user Models.User
-		if err := Database.DB.First(&user, claims.UserID).Error; err != nil {
+		user, err := Services.FindUserCached(Database.DB, claims.UserID)
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"success": false,
 				"message": "用户不存在",
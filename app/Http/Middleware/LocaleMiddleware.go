@@ -0,0 +1,67 @@
+package Middleware
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/I18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LocaleMiddleware 语言解析中间件
+type LocaleMiddleware struct {
+	BaseMiddleware
+	config *Config.I18nConfig
+}
+
+// NewLocaleMiddleware 创建语言解析中间件
+// 功能说明：
+// 1. 初始化语言解析中间件实例
+// 2. 读取国际化配置（支持的语言、查询参数名）
+// 3. 配置为空时使用全局配置
+func NewLocaleMiddleware(config *Config.I18nConfig) *LocaleMiddleware {
+	if config == nil {
+		config = Config.GetI18nConfig()
+	}
+	return &LocaleMiddleware{
+		config: config,
+	}
+}
+
+// Handle 处理语言解析
+// 功能说明：
+// 1. 按查询参数（默认 lang）、X-Locale 请求头、Accept-Language 解析请求语言
+// 2. 将语言写入gin上下文（locale）和请求上下文，供控制器和服务层使用
+// 3. 记录语言是否为显式指定（locale_explicit），显式指定时优先于用户偏好
+// 4. 设置 Content-Language 响应头
+//
+// 注意事项：
+// - 本中间件在认证之前执行，用户偏好语言由控制器在认证后解析
+func (m *LocaleMiddleware) Handle() gin.HandlerFunc {
+	queryParam := "lang"
+	var supported []string
+	if m.config != nil {
+		if m.config.QueryParam != "" {
+			queryParam = m.config.QueryParam
+		}
+		supported = m.config.Supported
+	}
+
+	return func(c *gin.Context) {
+		explicit := c.Query(queryParam)
+		if explicit == "" {
+			explicit = c.GetHeader("X-Locale")
+		}
+
+		locale := I18n.Negotiate(I18n.LocaleRequest{
+			Explicit:       explicit,
+			AcceptLanguage: c.GetHeader("Accept-Language"),
+		}, supported)
+
+		c.Set("locale", locale)
+		c.Set("locale_explicit", explicit != "" && I18n.MatchLocale([]string{explicit}, []string{locale}) != "")
+		c.Request = c.Request.WithContext(I18n.WithLocale(c.Request.Context(), locale))
+		c.Header("Content-Language", locale)
+
+		c.Next()
+	}
+}
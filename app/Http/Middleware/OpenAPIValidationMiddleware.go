@@ -0,0 +1,56 @@
+package Middleware
+
+import (
+	"cloud-platform-api/app/OpenAPI"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAPIValidationMiddleware 接口文档请求校验中间件
+type OpenAPIValidationMiddleware struct {
+	BaseMiddleware
+	registry *OpenAPI.Registry
+	strict   bool
+}
+
+// NewOpenAPIValidationMiddleware 创建接口文档请求校验中间件
+// registry 为空时使用全局注册表；strict 为 true 时拒绝未声明的查询参数和请求体字段
+func NewOpenAPIValidationMiddleware(registry *OpenAPI.Registry, strict bool) *OpenAPIValidationMiddleware {
+	if registry == nil {
+		registry = OpenAPI.DefaultRegistry()
+	}
+	return &OpenAPIValidationMiddleware{registry: registry, strict: strict}
+}
+
+// Handle 按接口文档校验请求
+// 功能说明：
+// 1. 按路由注册时声明的参数和请求体类型校验请求，未声明的路由直接放行
+// 2. 校验失败返回400，errors 为所有不符合的位置，格式与参数校验失败的响应一致
+//
+// 注意事项：
+// - 作为全局中间件时在认证之前执行，未登录的无效请求返回400而不是401
+func (m *OpenAPIValidationMiddleware) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := m.registry.Validate(c, m.strict)
+		var validationErr *OpenAPI.ValidationError
+		if errors.As(err, &validationErr) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "请求不符合接口文档",
+				"errors":  validationErr.Errors,
+			})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "读取请求失败",
+				"error":   err.Error(),
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,76 @@
+package Middleware
+
+import (
+	"cloud-platform-api/app/I18n"
+	"cloud-platform-api/app/Services"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 必须修改密码时仍允许访问的接口
+var passwordChangeAllowedPaths = map[string]bool{
+	"/api/v1/auth/change-password": true,
+	"/api/v1/auth/logout":          true,
+}
+
+// PasswordExpiryMiddleware 密码过期中间件
+type PasswordExpiryMiddleware struct {
+	BaseMiddleware
+	service *Services.PasswordExpiryService
+}
+
+// NewPasswordExpiryMiddleware 创建密码过期中间件
+// 功能说明：
+// 1. 检查已认证用户的密码是否超过更改间隔，过期时标记为必须修改密码
+// 2. 必须修改密码的用户只能访问修改密码和登出接口，其他请求返回403
+// 3. 通过上下文must_change_password和响应头X-Password-Change-Required告知后续处理器和客户端
+func NewPasswordExpiryMiddleware(service *Services.PasswordExpiryService) *PasswordExpiryMiddleware {
+	return &PasswordExpiryMiddleware{service: service}
+}
+
+// Check 检查当前用户是否允许继续访问，不允许时中止请求并返回false
+//
+// 需要在认证之后调用，上下文中没有用户ID时直接放行。
+func (m *PasswordExpiryMiddleware) Check(c *gin.Context) bool {
+	userID, err := strconv.ParseUint(c.GetString("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		return true
+	}
+
+	mustChange, err := m.service.Enforce(uint(userID))
+	if err != nil {
+		// 查询失败时不阻断请求，避免数据库故障导致所有用户无法访问
+		log.Printf("检查密码过期状态失败: user=%d, error=%v", userID, err)
+		return true
+	}
+	if !mustChange {
+		return true
+	}
+
+	c.Set("must_change_password", true)
+	c.Header("X-Password-Change-Required", "true")
+	if passwordChangeAllowedPaths[c.Request.URL.Path] {
+		return true
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{
+		"success": false,
+		"message": I18n.TContext(c.Request.Context(), "auth.password_change_required"),
+		"code":    "PASSWORD_CHANGE_REQUIRED",
+	})
+	c.Abort()
+	return false
+}
+
+// Handle 处理密码过期检查
+func (m *PasswordExpiryMiddleware) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.Check(c) {
+			return
+		}
+		c.Next()
+	}
+}
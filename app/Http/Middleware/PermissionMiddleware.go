@@ -1,9 +1,14 @@
 package Middleware
 
 import (
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Services"
 	"cloud-platform-api/app/Storage"
+	"errors"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -30,9 +35,10 @@ func NewPermissionMiddleware(storageManager *Storage.StorageManager) *Permission
 // 1. 检查用户是否具有指定角色
 // 2. 支持多个角色的OR逻辑
 // 3. 记录权限检查结果
+// 4. 启用模型缓存时按用户当前角色判断，令牌签发后角色变化无需等待令牌过期
 func (m *PermissionMiddleware) RequireRole(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userRole := c.GetString("user_role")
+		userRole := m.resolveRole(c.GetString("user_id"), c.GetString("user_role"))
 		if userRole == "" {
 			m.logPermissionDenied(c, "no_role", "用户未登录")
 			c.JSON(http.StatusUnauthorized, gin.H{
@@ -177,6 +183,27 @@ func (m *PermissionMiddleware) RequireOwnership(resourceType string) gin.Handler
 	}
 }
 
+// resolveRole 启用模型缓存时从缓存读取用户当前角色
+// 用户已被删除时返回空字符串；未启用缓存或读取失败时使用令牌中的角色
+func (m *PermissionMiddleware) resolveRole(userID, tokenRole string) string {
+	db := Database.GetDB()
+	if Services.DefaultModelCache() == nil || db == nil || userID == "" {
+		return tokenRole
+	}
+	id, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return tokenRole
+	}
+	user, err := Services.FindUserCached(db, uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ""
+	}
+	if err != nil || user.Role == "" {
+		return tokenRole
+	}
+	return user.Role
+}
+
 // checkUserPermissions 检查用户权限
 // 功能说明：
 // 1. 实现具体的权限检查逻辑
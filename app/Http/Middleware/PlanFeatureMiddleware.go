@@ -0,0 +1,71 @@
+package Middleware
+
+import (
+	"cloud-platform-api/app/I18n"
+	"cloud-platform-api/app/Services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PlanFeatureMiddleware 套餐功能中间件
+type PlanFeatureMiddleware struct {
+	BaseMiddleware
+	service *Services.BillingService
+}
+
+// NewPlanFeatureMiddleware 创建套餐功能中间件
+// 功能说明：
+// 1. 检查当前用户的套餐是否包含高级功能，不包含时返回402
+// 2. service 为 nil 时使用全局计费服务，未启用计费时所有功能都可以使用
+// 3. 管理员不受套餐限制
+func NewPlanFeatureMiddleware(service *Services.BillingService) *PlanFeatureMiddleware {
+	return &PlanFeatureMiddleware{service: service}
+}
+
+// Check 检查当前用户的套餐是否包含功能，不包含时中止请求并返回false
+//
+// 需要在认证之后调用；读取套餐失败时放行，避免计费数据库故障影响正常使用。
+func (m *PlanFeatureMiddleware) Check(c *gin.Context, feature string) bool {
+	service := m.service
+	if service == nil {
+		service = Services.DefaultBillingService()
+	}
+	if service == nil {
+		return true
+	}
+	userID, _ := strconv.ParseUint(c.GetString("user_id"), 10, 64)
+	err := service.RequireFeature(uint(userID), c.GetString("user_role"), feature)
+	var planErr *Services.PlanFeatureError
+	if errors.As(err, &planErr) {
+		AbortWithPlanFeatureRequired(c, planErr)
+		return false
+	}
+	return true
+}
+
+// Require 要求当前用户的套餐包含功能
+func (m *PlanFeatureMiddleware) Require(feature string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.Check(c, feature) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// AbortWithPlanFeatureRequired 返回套餐不包含所需功能的响应并中止请求
+func AbortWithPlanFeatureRequired(c *gin.Context, err *Services.PlanFeatureError) {
+	c.JSON(http.StatusPaymentRequired, gin.H{
+		"success": false,
+		"message": I18n.TContext(c.Request.Context(), "common.plan_feature_required", I18n.Params{
+			"feature": err.Feature,
+		}),
+		"code":    "PLAN_FEATURE_REQUIRED",
+		"feature": err.Feature,
+		"plan":    err.Plan,
+	})
+	c.Abort()
+}
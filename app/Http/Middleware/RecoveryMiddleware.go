@@ -1,6 +1,7 @@
 package Middleware
 
 import (
+	"cloud-platform-api/app/Services"
 	"cloud-platform-api/app/Storage"
 	"github.com/gin-gonic/gin"
 	"net/http"
@@ -11,6 +12,7 @@ import (
 type RecoveryMiddleware struct {
 	BaseMiddleware
 	storageManager *Storage.StorageManager
+	reporter       *Services.ErrorReporter
 }
 
 // NewRecoveryMiddleware 创建错误恢复中间件
@@ -25,23 +27,38 @@ func NewRecoveryMiddleware(storageManager *Storage.StorageManager) *RecoveryMidd
 	}
 }
 
+// SetErrorReporter 设置错误上报服务，panic 会上报到 Sentry/Bugsnag
+//
+// reporter 为 nil 时不上报，也不创建请求错误上下文。
+func (m *RecoveryMiddleware) SetErrorReporter(reporter *Services.ErrorReporter) {
+	m.reporter = reporter
+}
+
 // Handle 处理错误恢复
 // 功能说明：
 // 1. 使用defer recover()捕获panic
 // 2. 记录详细的错误信息和堆栈跟踪
 // 3. 返回500内部服务器错误响应
 // 4. 防止应用因panic而崩溃
+// 5. 启用错误上报时为每个请求创建错误上下文（请求信息、面包屑），panic 连同上下文一起上报
 func (m *RecoveryMiddleware) Handle() gin.HandlerFunc {
-	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
+	recovery := gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
+		// 在恢复 panic 的 defer 中上报，堆栈才包含发生 panic 的位置
+		if m.reporter != nil {
+			m.reporter.CapturePanic(c, recovered, nil)
+		}
+
 		// 记录错误信息
-		m.storageManager.LogError("应用发生panic", map[string]interface{}{
-			"error":        recovered,
-			"url":          c.Request.URL.String(),
-			"method":       c.Request.Method,
-			"client_ip":    c.ClientIP(),
-			"user_agent":   c.Request.UserAgent(),
-			"stack_trace":  string(debug.Stack()),
-		})
+		if m.storageManager != nil {
+			m.storageManager.LogError("应用发生panic", map[string]interface{}{
+				"error":       recovered,
+				"url":         c.Request.URL.String(),
+				"method":      c.Request.Method,
+				"client_ip":   c.ClientIP(),
+				"user_agent":  c.Request.UserAgent(),
+				"stack_trace": string(debug.Stack()),
+			})
+		}
 
 		// 返回错误响应
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -50,4 +67,16 @@ func (m *RecoveryMiddleware) Handle() gin.HandlerFunc {
 			"error":   "An unexpected error occurred",
 		})
 	})
+	if m.reporter == nil {
+		return recovery
+	}
+
+	return func(c *gin.Context) {
+		// 错误上下文同时保存到 gin.Context 和请求上下文，服务层使用任一上下文都能添加面包屑
+		scope := m.reporter.NewScope()
+		scope.SetRequest(c.Request, c.ClientIP())
+		c.Set(Services.ErrorScopeKey, scope)
+		c.Request = c.Request.WithContext(Services.WithErrorScope(c.Request.Context(), scope))
+		recovery(c)
+	}
 }
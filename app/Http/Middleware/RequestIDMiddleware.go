@@ -0,0 +1,43 @@
+package Middleware
+
+import (
+	"cloud-platform-api/app/Utils"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDMiddleware 请求ID和关联ID中间件
+type RequestIDMiddleware struct {
+	BaseMiddleware
+}
+
+// NewRequestIDMiddleware 创建请求ID中间件
+// 功能说明：
+// 1. 接受客户端或上游网关传入的 X-Request-ID，未传入或格式无效时生成新的请求ID
+// 2. 接受传入的 X-Correlation-ID，未传入时使用请求ID，跨系统调用链共用同一个关联ID
+// 3. 两个ID设置到响应头、gin.Context（request_id、correlation_id）和请求上下文中
+// 4. 日志、审计记录、安全事件、领域事件和出站请求（Webhook、通知）从上下文中读取并携带这两个ID
+func NewRequestIDMiddleware() *RequestIDMiddleware {
+	return &RequestIDMiddleware{}
+}
+
+// Handle 处理请求ID
+func (m *RequestIDMiddleware) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(Utils.RequestIDHeader)
+		if !Utils.ValidRequestID(requestID) {
+			requestID = Utils.NewRequestID()
+		}
+		correlationID := c.GetHeader(Utils.CorrelationIDHeader)
+		if !Utils.ValidRequestID(correlationID) {
+			correlationID = requestID
+		}
+
+		c.Set(Utils.RequestIDKey, requestID)
+		c.Set(Utils.CorrelationIDKey, correlationID)
+		c.Header(Utils.RequestIDHeader, requestID)
+		c.Header(Utils.CorrelationIDHeader, correlationID)
+		c.Request = c.Request.WithContext(Utils.WithRequestIDs(c.Request.Context(), requestID, correlationID))
+
+		c.Next()
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
 	"net"
 	"net/http"
 	"strings"
@@ -86,24 +87,30 @@ func (m *RequestLogMiddleware) RequestLog() gin.HandlerFunc {
 		// 某些路径（如健康检查、静态资源）可能不需要记录
 		if m.shouldSkipPath(c.Request.URL.Path) {
 			c.Next()
+			m.logAccess(c, startTime)
 			return
 		}
 
-		// 读取请求体（如果配置了）
-		// 注意：读取后需要重新设置到请求中，以便后续处理
-		var requestBody []byte
-		if m.config.IncludeBody && m.config.MaxBodySize > 0 {
+		// 是否记录请求体和响应体：需要开启配置，且路径没有被排除
+		capture := m.config.IncludeBody && m.config.MaxBodySize > 0 && !m.shouldSkipBody(c.Request.URL.Path)
+
+		// 读取请求体（只读取配置的大小，剩余部分原样留给后续处理器）
+		var requestBody *capturedBody
+		if capture && m.isCapturableType(c.GetHeader("Content-Type")) {
 			requestBody = m.readRequestBody(c)
 		}
 
 		// 创建响应写入器包装器
-		// 用于捕获响应体，同时不影响正常的响应写入
-		responseWriter := &responseBodyWriter{
-			ResponseWriter: c.Writer,
-			body:           &bytes.Buffer{},
+		// 最多缓存 MaxBodySize KB，不影响正常的响应写入（包括流式响应）
+		var responseWriter *responseBodyWriter
+		if capture {
+			responseWriter = &responseBodyWriter{
+				ResponseWriter: c.Writer,
+				body:           &bytes.Buffer{},
+				limit:          m.config.MaxBodyBytes(),
+			}
+			c.Writer = responseWriter
 		}
-		// 替换响应写入器，以便捕获响应体
-		c.Writer = responseWriter
 
 		// 处理请求（执行后续中间件和处理器）
 		c.Next()
@@ -111,19 +118,44 @@ func (m *RequestLogMiddleware) RequestLog() gin.HandlerFunc {
 		// 计算处理时间
 		duration := time.Since(startTime)
 
-		// 读取响应体（如果配置了）
-		// 从响应写入器包装器中获取响应体
-		var responseBody []byte
-		if m.config.IncludeBody && m.config.MaxBodySize > 0 {
-			responseBody = m.readResponseBody(responseWriter)
+		// 路由级退出：路由模板在排除列表中，或路由使用了 SkipBodyLogging 中间件
+		if capture && (c.GetBool(requestLogSkipBodyKey) || m.shouldSkipBody(c.FullPath())) {
+			capture = false
+			requestBody = nil
+		}
+
+		// 读取响应体（只记录配置的内容类型）
+		var responseBody *capturedBody
+		if capture && m.isCapturableType(c.Writer.Header().Get("Content-Type")) {
+			responseBody = responseWriter.captured()
 		}
 
 		// 记录请求日志
 		// 包含所有请求和响应的详细信息
 		m.logRequest(c, startTime, duration, requestBody, responseBody)
+		m.logAccess(c, startTime)
 	}
 }
 
+// requestLogSkipBodyKey 路由级关闭请求/响应体记录的上下文键
+const requestLogSkipBodyKey = "request_log_skip_body"
+
+// SkipBodyLogging 路由级中间件：不记录该路由的请求体和响应体
+//
+// 适用于文件下载、流式响应或包含大量敏感数据的接口，请求本身仍然会记录。
+func SkipBodyLogging() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(requestLogSkipBodyKey, true)
+		c.Next()
+	}
+}
+
+// capturedBody 捕获的请求体或响应体
+type capturedBody struct {
+	data      []byte
+	truncated bool
+}
+
 // shouldSkipPath 检查是否应该跳过记录此路径
 func (m *RequestLogMiddleware) shouldSkipPath(path string) bool {
 	for _, filterPath := range m.config.FilterPaths {
@@ -134,95 +166,101 @@ func (m *RequestLogMiddleware) shouldSkipPath(path string) bool {
 	return false
 }
 
+// shouldSkipBody 检查路径或路由模板是否不记录请求/响应体
+func (m *RequestLogMiddleware) shouldSkipBody(path string) bool {
+	if path == "" {
+		return false
+	}
+	for _, skipPath := range m.config.SkipBodyPaths {
+		if path == skipPath || strings.HasPrefix(path, skipPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCapturableType 检查内容类型是否需要记录（默认只记录JSON）
+func (m *RequestLogMiddleware) isCapturableType(contentType string) bool {
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	if contentType == "" {
+		return false
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mediaType
+	}
+	for _, allowed := range m.config.BodyContentTypes {
+		if strings.HasPrefix(contentType, strings.ToLower(strings.TrimSpace(allowed))) {
+			return true
+		}
+	}
+	return false
+}
+
 // readRequestBody 读取请求体
-func (m *RequestLogMiddleware) readRequestBody(c *gin.Context) []byte {
-	if c.Request.Body == nil {
+//
+// 只读取 MaxBodySize KB 再加1字节用于判断是否截断，已读取的部分和剩余部分重新拼接，
+// 保证后续处理器读到完整的请求体。
+func (m *RequestLogMiddleware) readRequestBody(c *gin.Context) *capturedBody {
+	return captureRequestBody(c, m.config.MaxBodyBytes())
+}
+
+// captureRequestBody 读取请求体的前 limit 字节，请求体保持完整
+func captureRequestBody(c *gin.Context, limit int) *capturedBody {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
 		return nil
 	}
 
-	// 限制读取大小
-	limitedReader := io.LimitReader(c.Request.Body, int64(m.config.MaxBodySize*1024))
-	body, err := io.ReadAll(limitedReader)
-	if err != nil {
+	original := c.Request.Body
+	prefix, err := io.ReadAll(io.LimitReader(original, int64(limit)+1))
+	c.Request.Body = &multiReadCloser{
+		Reader: io.MultiReader(bytes.NewReader(prefix), original),
+		Closer: original,
+	}
+	if err != nil || len(prefix) == 0 {
 		return nil
 	}
 
-	// 重新设置请求体，以便后续处理
-	c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
-
-	// 脱敏处理
-	return m.maskSensitiveFields(body)
+	body := &capturedBody{data: prefix}
+	if len(prefix) > limit {
+		body.data = prefix[:limit]
+		body.truncated = true
+	}
+	return body
 }
 
-// readResponseBody 读取响应体
-func (m *RequestLogMiddleware) readResponseBody(writer *responseBodyWriter) []byte {
-	body := writer.body.Bytes()
-	if len(body) > m.config.MaxBodySize*1024 {
-		body = body[:m.config.MaxBodySize*1024]
-	}
-	return m.maskSensitiveFields(body)
+// multiReadCloser 组合读取器和原始请求体的关闭方法
+type multiReadCloser struct {
+	io.Reader
+	io.Closer
 }
 
-// maskSensitiveFields 脱敏敏感字段
+// bodyValue 将请求体/响应体转换为日志字段
 //
 // 功能说明：
-// 1. 检测并脱敏JSON数据中的敏感字段
-// 2. 支持嵌套对象和数组的递归脱敏
-// 3. 非JSON格式的数据直接返回（不处理）
-//
-// 脱敏策略：
-// - 检测字段名是否包含敏感关键词（如password、token等）
-// - 将敏感字段的值替换为"***MASKED***"
-// - 递归处理嵌套对象和数组
+// 1. 完整的JSON解析为结构化数据，便于日志检索，并按 MaskFields 递归脱敏
+// 2. 截断或无法解析的内容按原文记录，由日志管理器的脱敏规则处理
+// 3. 数字保持原始精度（json.Number），避免大整数ID失真
 //
 // 支持的格式：
 // - JSON对象：{"password": "123456"} -> {"password": "***MASKED***"}
 // - 嵌套对象：{"user": {"password": "123456"}} -> {"user": {"password": "***MASKED***"}}
 // - 数组：[{"password": "123456"}] -> [{"password": "***MASKED***"}]
-//
-// 性能考虑：
-// - JSON解析和序列化可能较慢
-// - 大文件可能占用较多内存
-// - 非JSON格式的数据不处理，直接返回
-//
-// 安全考虑：
-// - 敏感字段检测基于字段名，需要正确配置
-// - 脱敏后的数据仍然可能包含其他敏感信息
-// - 建议在生产环境中谨慎记录请求体和响应体
-//
-// 注意事项：
-// - 如果JSON解析失败，返回原始数据
-// - 如果序列化失败，返回原始数据
-// - 脱敏操作不会修改原始数据，返回新数据
-func (m *RequestLogMiddleware) maskSensitiveFields(data []byte) []byte {
-	// 如果数据为空，直接返回
-	if len(data) == 0 {
-		return data
-	}
-
-	// 尝试解析JSON
-	// 如果不是JSON格式，无法进行脱敏处理
-	var jsonData map[string]interface{}
-	if err := json.Unmarshal(data, &jsonData); err != nil {
-		// 不是JSON格式，直接返回原始数据
-		// 非JSON格式的数据（如纯文本、二进制）不进行脱敏
-		return data
-	}
-
-	// 脱敏处理
-	// 递归处理所有嵌套对象和数组
-	m.maskMap(jsonData)
-
-	// 重新序列化为JSON
-	result, err := json.Marshal(jsonData)
-	if err != nil {
-		// 序列化失败，返回原始数据
-		// 这通常不应该发生，但为了安全起见，返回原始数据
-		return data
-	}
-
-	// 返回脱敏后的数据
-	return result
+func (m *RequestLogMiddleware) bodyValue(body *capturedBody) interface{} {
+	if !body.truncated {
+		decoder := json.NewDecoder(bytes.NewReader(body.data))
+		decoder.UseNumber()
+		var value interface{}
+		if err := decoder.Decode(&value); err == nil && !decoder.More() {
+			switch v := value.(type) {
+			case map[string]interface{}:
+				m.maskMap(v)
+			case []interface{}:
+				m.maskArray(v)
+			}
+			return value
+		}
+	}
+	return string(body.data)
 }
 
 // maskMap 递归脱敏map中的敏感字段
@@ -270,7 +308,7 @@ func (m *RequestLogMiddleware) isSensitiveField(fieldName string) bool {
 }
 
 // logRequest 记录请求日志
-func (m *RequestLogMiddleware) logRequest(c *gin.Context, startTime time.Time, duration time.Duration, requestBody, responseBody []byte) {
+func (m *RequestLogMiddleware) logRequest(c *gin.Context, startTime time.Time, duration time.Duration, requestBody, responseBody *capturedBody) {
 	// 构建日志字段
 	fields := map[string]interface{}{
 		"method":         c.Request.Method,
@@ -285,6 +323,7 @@ func (m *RequestLogMiddleware) logRequest(c *gin.Context, startTime time.Time, d
 		"referer":        c.Request.Referer(),
 		"protocol":       c.Request.Proto,
 		"host":           c.Request.Host,
+		"response_size":  c.Writer.Size(),
 	}
 
 	// 添加用户信息（如果可用）
@@ -316,14 +355,19 @@ func (m *RequestLogMiddleware) logRequest(c *gin.Context, startTime time.Time, d
 	}
 	fields["response_headers"] = responseHeaders
 
-	// 添加请求体（如果配置了）
-	if m.config.IncludeBody && len(requestBody) > 0 {
-		fields["request_body"] = string(requestBody)
+	// 添加请求体和响应体（如果配置了）
+	// JSON解析为结构化字段，截断的内容按原文记录并标记
+	if requestBody != nil {
+		fields["request_body"] = m.bodyValue(requestBody)
+		if requestBody.truncated {
+			fields["request_body_truncated"] = true
+		}
 	}
-
-	// 添加响应体（如果配置了）
-	if m.config.IncludeBody && len(responseBody) > 0 {
-		fields["response_body"] = string(responseBody)
+	if responseBody != nil {
+		fields["response_body"] = m.bodyValue(responseBody)
+		if responseBody.truncated {
+			fields["response_body_truncated"] = true
+		}
 	}
 
 	// 添加错误信息（如果有）
@@ -343,6 +387,44 @@ func (m *RequestLogMiddleware) logRequest(c *gin.Context, startTime time.Time, d
 	m.logManager.LogRequest(c.Request.Context(), c.Request.Method, c.Request.URL.Path, c.Writer.Status(), duration, fields)
 }
 
+// logAccess 记录访问日志
+// 访问日志每个请求一行，记录所有请求（不受 REQUEST_LOG_FILTER_PATHS 影响），
+// 字段满足 combined/common 格式的需要，ACCESS_LOG_INCLUDE_* 控制是否记录IP、用户和User-Agent
+func (m *RequestLogMiddleware) logAccess(c *gin.Context, startTime time.Time) {
+	access := &m.logManager.GetConfig().AccessLog
+	if !access.Enabled {
+		return
+	}
+	uri := c.Request.RequestURI
+	if uri == "" {
+		uri = c.Request.URL.RequestURI()
+	}
+	fields := map[string]interface{}{
+		"uri":           uri,
+		"protocol":      c.Request.Proto,
+		"response_size": c.Writer.Size(),
+		"referer":       c.Request.Referer(),
+		"duration_ms":   time.Since(startTime).Milliseconds(),
+		"start_time":    startTime.Format(time.RFC3339Nano),
+	}
+	if access.IncludeIP {
+		fields["client_ip"] = c.ClientIP()
+	}
+	if access.IncludeUser {
+		if username, exists := c.Get("username"); exists {
+			fields["username"] = username
+		}
+		if userID, exists := c.Get("user_id"); exists {
+			fields["user_id"] = userID
+		}
+	}
+	userAgent := ""
+	if access.IncludeUA {
+		userAgent = c.Request.UserAgent()
+	}
+	m.logManager.LogAccess(c.Request.Context(), c.Request.Method, c.Request.URL.Path, c.Writer.Status(), userAgent, fields)
+}
+
 // buildLogMessage 构建日志消息
 func (m *RequestLogMiddleware) buildLogMessage(c *gin.Context, duration time.Duration) string {
 	statusCode := c.Writer.Status()
@@ -364,23 +446,51 @@ func (m *RequestLogMiddleware) buildLogMessage(c *gin.Context, duration time.Dur
 }
 
 // responseBodyWriter 响应体写入器包装器
+//
+// 最多缓存 limit 字节的响应体，超出部分只计数不缓存，避免大响应和流式响应占用内存。
 type responseBodyWriter struct {
 	gin.ResponseWriter
-	body *bytes.Buffer
+	body      *bytes.Buffer
+	limit     int
+	truncated bool
 }
 
 // Write 写入响应体
 func (w *responseBodyWriter) Write(b []byte) (int, error) {
-	w.body.Write(b)
+	w.capture(b)
 	return w.ResponseWriter.Write(b)
 }
 
 // WriteString 写入响应字符串
 func (w *responseBodyWriter) WriteString(s string) (int, error) {
-	w.body.WriteString(s)
+	w.capture([]byte(s))
 	return w.ResponseWriter.WriteString(s)
 }
 
+// capture 缓存响应体，超出限制时截断
+func (w *responseBodyWriter) capture(b []byte) {
+	if remaining := w.limit - w.body.Len(); remaining > 0 {
+		if len(b) > remaining {
+			w.body.Write(b[:remaining])
+			w.truncated = true
+			return
+		}
+		w.body.Write(b)
+		return
+	}
+	if len(b) > 0 {
+		w.truncated = true
+	}
+}
+
+// captured 获取缓存的响应体
+func (w *responseBodyWriter) captured() *capturedBody {
+	if w == nil || w.body.Len() == 0 {
+		return nil
+	}
+	return &capturedBody{data: w.body.Bytes(), truncated: w.truncated}
+}
+
 // WriteHeader 写入响应头
 func (w *responseBodyWriter) WriteHeader(code int) {
 	w.ResponseWriter.WriteHeader(code)
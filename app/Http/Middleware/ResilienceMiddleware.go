@@ -0,0 +1,535 @@
+package Middleware
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Services"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 依赖熔断器状态，与 CircuitBreaker.GetStats 中的状态名称一致
+const (
+	DependencyStateClosed   = "closed"
+	DependencyStateOpen     = "open"
+	DependencyStateHalfOpen = "half-open"
+)
+
+// 依赖熔断状态变化告警类型
+const (
+	ResilienceAlertDependencyOpen      = "dependency_circuit_open"
+	ResilienceAlertDependencyHalfOpen  = "dependency_circuit_half_open"
+	ResilienceAlertDependencyRecovered = "dependency_circuit_recovered"
+)
+
+// DependencyCheck 依赖健康检查函数，返回错误表示依赖不可用
+type DependencyCheck func(ctx context.Context) error
+
+// DependencyBreakerStats 依赖熔断器状态
+type DependencyBreakerStats struct {
+	Name                 string    `json:"name"`
+	Required             bool      `json:"required"`
+	State                string    `json:"state"`
+	ConsecutiveFailures  int       `json:"consecutive_failures"`
+	ConsecutiveSuccesses int       `json:"consecutive_successes"`
+	LastError            string    `json:"last_error,omitempty"`
+	LastCheck            time.Time `json:"last_check"`
+	OpenedAt             time.Time `json:"opened_at,omitempty"`
+	StateChanges         int64     `json:"state_changes"`
+	Rejected             int64     `json:"rejected"`
+}
+
+// BulkheadStats 路由舱壁状态
+type BulkheadStats struct {
+	Route    string `json:"route"`
+	Limit    int    `json:"limit"`
+	InFlight int    `json:"in_flight"`
+	Rejected int64  `json:"rejected"`
+}
+
+// ResilienceStats 弹性保护状态
+type ResilienceStats struct {
+	Dependencies []DependencyBreakerStats `json:"dependencies"`
+	Bulkheads    []BulkheadStats          `json:"bulkheads"`
+}
+
+// dependencyBreaker 单个依赖的熔断器
+type dependencyBreaker struct {
+	name                 string
+	check                DependencyCheck
+	required             bool
+	state                string
+	consecutiveFailures  int
+	consecutiveSuccesses int
+	lastError            string
+	lastCheck            time.Time
+	openedAt             time.Time
+	stateChanges         int64
+	rejected             int64
+}
+
+// bulkhead 单个路由的并发名额
+type bulkhead struct {
+	route    string
+	limit    int
+	slots    chan struct{}
+	rejected int64
+}
+
+// ResilienceMiddleware 入站请求弹性保护中间件
+// 功能说明：
+// 1. 定期检查数据库、Redis等依赖的健康状态，连续失败达到阈值时熔断
+// 2. 必需依赖熔断期间直接返回503和Retry-After，不再占用连接和处理时间
+// 3. 熔断超时后进入半开状态放行请求，健康检查连续成功后恢复
+// 4. 按路由模板限制并发请求数（舱壁隔离），名额用尽时返回503
+// 5. 熔断状态变化时记录日志并通过 OnAlert 回调发出告警
+type ResilienceMiddleware struct {
+	BaseMiddleware
+	config       *Config.ResilienceConfig
+	routeLimits  map[string]int
+	dependencies map[string]*dependencyBreaker
+	bulkheads    map[string]*bulkhead
+	alertHandler func(Services.MonitoringAlert)
+	// pendingAlerts 状态变化时生成、等待在锁外发送的告警
+	pendingAlerts []Services.MonitoringAlert
+	mutex         sync.RWMutex
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	isRunning bool
+}
+
+// NewResilienceMiddleware 创建弹性保护中间件
+//
+// config 为 nil 时使用全局配置，未加载配置时使用默认值。
+func NewResilienceMiddleware(config *Config.ResilienceConfig) *ResilienceMiddleware {
+	if config == nil {
+		if globalConfig := Config.GetConfig(); globalConfig != nil {
+			config = &globalConfig.Resilience
+		} else {
+			config = &Config.ResilienceConfig{}
+			config.SetDefaults()
+		}
+	}
+
+	routeLimits, err := config.ParseRouteLimits()
+	if err != nil {
+		log.Printf("路由并发数配置无效，忽略单独配置: %v", err)
+		routeLimits = map[string]int{}
+	}
+
+	return &ResilienceMiddleware{
+		config:       config,
+		routeLimits:  routeLimits,
+		dependencies: make(map[string]*dependencyBreaker),
+		bulkheads:    make(map[string]*bulkhead),
+	}
+}
+
+// RegisterDependency 注册需要健康检查的依赖
+//
+// 依赖名称出现在 RequiredDependencies 中时，熔断期间拒绝请求；否则只记录状态和告警。
+func (m *ResilienceMiddleware) RegisterDependency(name string, check DependencyCheck) {
+	required := false
+	for _, dependency := range m.config.CircuitBreaker.RequiredDependencies {
+		if strings.EqualFold(strings.TrimSpace(dependency), name) {
+			required = true
+			break
+		}
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.dependencies[name] = &dependencyBreaker{
+		name:     name,
+		check:    check,
+		required: required,
+		state:    DependencyStateClosed,
+	}
+}
+
+// OnAlert 设置熔断状态变化的告警回调
+func (m *ResilienceMiddleware) OnAlert(handler func(Services.MonitoringAlert)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.alertHandler = handler
+}
+
+// Start 启动依赖健康检查
+func (m *ResilienceMiddleware) Start() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.isRunning {
+		return fmt.Errorf("依赖健康检查已在运行")
+	}
+	if !m.config.Enabled || !m.config.CircuitBreaker.Enabled {
+		return nil
+	}
+
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	m.isRunning = true
+	go m.checkLoop(m.ctx)
+	return nil
+}
+
+// Stop 停止依赖健康检查
+func (m *ResilienceMiddleware) Stop() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.isRunning {
+		return
+	}
+	m.cancel()
+	m.isRunning = false
+}
+
+// checkLoop 按检查间隔执行依赖健康检查
+func (m *ResilienceMiddleware) checkLoop(ctx context.Context) {
+	ticker := time.NewTicker(m.config.CircuitBreaker.CheckInterval)
+	defer ticker.Stop()
+
+	m.CheckDependencies(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.CheckDependencies(ctx)
+		}
+	}
+}
+
+// CheckDependencies 检查所有依赖并更新熔断状态
+//
+// 熔断中的依赖在等待时间结束前不检查，结束后进入半开状态再检查。
+func (m *ResilienceMiddleware) CheckDependencies(ctx context.Context) {
+	m.mutex.RLock()
+	dependencies := make([]*dependencyBreaker, 0, len(m.dependencies))
+	for _, dependency := range m.dependencies {
+		dependencies = append(dependencies, dependency)
+	}
+	m.mutex.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, dependency := range dependencies {
+		m.mutex.Lock()
+		if dependency.state == DependencyStateOpen && time.Since(dependency.openedAt) >= m.config.CircuitBreaker.OpenTimeout {
+			m.transition(dependency, DependencyStateHalfOpen)
+		}
+		skip := dependency.state == DependencyStateOpen
+		m.mutex.Unlock()
+		if skip {
+			continue
+		}
+
+		wg.Add(1)
+		go func(dependency *dependencyBreaker) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, m.config.CircuitBreaker.CheckTimeout)
+			defer cancel()
+			m.recordCheck(dependency, dependency.check(checkCtx))
+		}(dependency)
+	}
+	wg.Wait()
+	m.flushAlerts()
+}
+
+// recordCheck 记录一次健康检查结果
+func (m *ResilienceMiddleware) recordCheck(dependency *dependencyBreaker, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	dependency.lastCheck = time.Now()
+	if err != nil {
+		dependency.lastError = err.Error()
+		dependency.consecutiveSuccesses = 0
+		dependency.consecutiveFailures++
+		// 半开状态下一次失败即重新熔断
+		if dependency.state == DependencyStateHalfOpen ||
+			(dependency.state == DependencyStateClosed && dependency.consecutiveFailures >= m.config.CircuitBreaker.FailureThreshold) {
+			m.transition(dependency, DependencyStateOpen)
+		}
+		return
+	}
+
+	dependency.consecutiveFailures = 0
+	dependency.consecutiveSuccesses++
+	if dependency.state == DependencyStateHalfOpen && dependency.consecutiveSuccesses >= m.config.CircuitBreaker.SuccessThreshold {
+		dependency.lastError = ""
+		m.transition(dependency, DependencyStateClosed)
+	}
+}
+
+// transition 切换依赖熔断状态，调用方需持有写锁
+func (m *ResilienceMiddleware) transition(dependency *dependencyBreaker, state string) {
+	previous := dependency.state
+	dependency.state = state
+	dependency.stateChanges++
+	if state == DependencyStateOpen {
+		dependency.openedAt = time.Now()
+	}
+	if state != DependencyStateClosed {
+		dependency.consecutiveSuccesses = 0
+	}
+
+	m.LogWarning("依赖熔断状态变化", map[string]interface{}{
+		"dependency": dependency.name,
+		"from":       previous,
+		"to":         state,
+		"required":   dependency.required,
+		"last_error": dependency.lastError,
+	})
+
+	if m.alertHandler != nil {
+		m.pendingAlerts = append(m.pendingAlerts, m.buildAlert(dependency, previous))
+	}
+}
+
+// flushAlerts 在锁外发送待发送的告警，避免回调中访问中间件时死锁
+func (m *ResilienceMiddleware) flushAlerts() {
+	m.mutex.Lock()
+	handler, alerts := m.alertHandler, m.pendingAlerts
+	m.pendingAlerts = nil
+	m.mutex.Unlock()
+
+	for _, alert := range alerts {
+		handler(alert)
+	}
+}
+
+// buildAlert 构建状态变化告警
+func (m *ResilienceMiddleware) buildAlert(dependency *dependencyBreaker, previous string) Services.MonitoringAlert {
+	now := time.Now()
+	alertType, severity := ResilienceAlertDependencyOpen, "critical"
+	message := fmt.Sprintf("依赖 %s 连续 %d 次健康检查失败，已熔断: %s", dependency.name, dependency.consecutiveFailures, dependency.lastError)
+	switch dependency.state {
+	case DependencyStateHalfOpen:
+		alertType, severity = ResilienceAlertDependencyHalfOpen, "warning"
+		message = fmt.Sprintf("依赖 %s 熔断等待结束，进入半开状态", dependency.name)
+	case DependencyStateClosed:
+		alertType, severity = ResilienceAlertDependencyRecovered, "info"
+		message = fmt.Sprintf("依赖 %s 健康检查连续 %d 次成功，已恢复", dependency.name, dependency.consecutiveSuccesses)
+	}
+	if !dependency.required && severity == "critical" {
+		severity = "warning"
+	}
+
+	return Services.MonitoringAlert{
+		ID:        fmt.Sprintf("%s_%s_%d", alertType, dependency.name, now.UnixNano()),
+		Type:      alertType,
+		Severity:  severity,
+		Title:     fmt.Sprintf("依赖熔断状态变化: %s", dependency.name),
+		Message:   message,
+		Source:    "resilience_middleware",
+		Timestamp: now,
+		Metadata: map[string]interface{}{
+			"dependency": dependency.name,
+			"required":   dependency.required,
+			"from":       previous,
+			"to":         dependency.state,
+			"last_error": dependency.lastError,
+		},
+	}
+}
+
+// Handle 弹性保护中间件处理函数
+func (m *ResilienceMiddleware) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.config.Enabled {
+			c.Next()
+			return
+		}
+
+		if m.config.CircuitBreaker.Enabled && !m.isExcluded(c.Request.URL.Path) {
+			if dependency, retryAfter := m.openDependency(); dependency != "" {
+				m.LogWarning("依赖熔断，拒绝请求", map[string]interface{}{
+					"path":       c.Request.URL.Path,
+					"method":     c.Request.Method,
+					"dependency": dependency,
+				})
+				c.Header("Retry-After", strconv.Itoa(retrySeconds(retryAfter)))
+				c.JSON(http.StatusServiceUnavailable, gin.H{
+					"success": false,
+					"message": "依赖服务暂时不可用，请稍后重试",
+					"code":    "DEPENDENCY_UNAVAILABLE",
+				})
+				c.Abort()
+				return
+			}
+		}
+
+		// 事件流和WebSocket连接持续占用名额直到断开，不受舱壁限制
+		if m.config.Bulkhead.Enabled && !longLivedRequest(c.Request) {
+			if bulkhead := m.getBulkhead(c.Request.Method, c.FullPath()); bulkhead != nil {
+				if !bulkhead.acquire(c.Request.Context(), m.config.Bulkhead.MaxWait) {
+					atomic.AddInt64(&bulkhead.rejected, 1)
+					m.LogWarning("路由并发数已满，拒绝请求", map[string]interface{}{
+						"route": bulkhead.route,
+						"limit": bulkhead.limit,
+					})
+					c.Header("Retry-After", strconv.Itoa(retrySeconds(m.config.Bulkhead.MaxWait)))
+					c.JSON(http.StatusServiceUnavailable, gin.H{
+						"success": false,
+						"message": "服务繁忙，请稍后重试",
+						"code":    "BULKHEAD_FULL",
+					})
+					c.Abort()
+					return
+				}
+				defer bulkhead.release()
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// isExcluded 检查路径是否不受熔断影响
+func (m *ResilienceMiddleware) isExcluded(path string) bool {
+	for _, prefix := range m.config.CircuitBreaker.ExcludePaths {
+		if prefix = strings.TrimSpace(prefix); prefix != "" && strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// longLivedRequest 是否为WebSocket升级或事件流请求
+func longLivedRequest(req *http.Request) bool {
+	if strings.EqualFold(req.Header.Get("Upgrade"), "websocket") {
+		return true
+	}
+	return strings.Contains(req.Header.Get("Accept"), "text/event-stream")
+}
+
+// openDependency 返回处于熔断状态的必需依赖名称和距离半开的剩余时间
+func (m *ResilienceMiddleware) openDependency() (string, time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, dependency := range m.dependencies {
+		if dependency.required && dependency.state == DependencyStateOpen {
+			dependency.rejected++
+			return dependency.name, m.config.CircuitBreaker.OpenTimeout - time.Since(dependency.openedAt)
+		}
+	}
+	return "", 0
+}
+
+// getBulkhead 获取路由的舱壁，未匹配到路由或不限制并发时返回 nil
+func (m *ResilienceMiddleware) getBulkhead(method, route string) *bulkhead {
+	if route == "" {
+		return nil
+	}
+	key := method + " " + route
+
+	m.mutex.RLock()
+	b, exists := m.bulkheads[key]
+	m.mutex.RUnlock()
+	if exists {
+		return b
+	}
+
+	limit, ok := m.routeLimits[key]
+	if !ok {
+		if limit, ok = m.routeLimits[route]; !ok {
+			limit = m.config.Bulkhead.DefaultLimit
+		}
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if b, exists = m.bulkheads[key]; !exists {
+		if limit > 0 {
+			b = &bulkhead{route: key, limit: limit, slots: make(chan struct{}, limit)}
+		}
+		// 不限制并发的路由也记录下来，避免每次请求重复解析
+		m.bulkheads[key] = b
+	}
+	return b
+}
+
+// acquire 获取并发名额，超过等待时间或请求取消时返回 false
+func (b *bulkhead) acquire(ctx context.Context, maxWait time.Duration) bool {
+	select {
+	case b.slots <- struct{}{}:
+		return true
+	default:
+	}
+	if maxWait <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+	select {
+	case b.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// release 释放并发名额
+func (b *bulkhead) release() {
+	<-b.slots
+}
+
+// retrySeconds 转换为 Retry-After 秒数，至少为1秒
+func retrySeconds(d time.Duration) int {
+	return int(math.Max(1, math.Ceil(d.Seconds())))
+}
+
+// Stats 获取依赖熔断和路由舱壁状态
+func (m *ResilienceMiddleware) Stats() ResilienceStats {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	stats := ResilienceStats{
+		Dependencies: make([]DependencyBreakerStats, 0, len(m.dependencies)),
+		Bulkheads:    make([]BulkheadStats, 0, len(m.bulkheads)),
+	}
+	for _, dependency := range m.dependencies {
+		stats.Dependencies = append(stats.Dependencies, DependencyBreakerStats{
+			Name:                 dependency.name,
+			Required:             dependency.required,
+			State:                dependency.state,
+			ConsecutiveFailures:  dependency.consecutiveFailures,
+			ConsecutiveSuccesses: dependency.consecutiveSuccesses,
+			LastError:            dependency.lastError,
+			LastCheck:            dependency.lastCheck,
+			OpenedAt:             dependency.openedAt,
+			StateChanges:         dependency.stateChanges,
+			Rejected:             dependency.rejected,
+		})
+	}
+	for _, b := range m.bulkheads {
+		if b == nil {
+			continue
+		}
+		stats.Bulkheads = append(stats.Bulkheads, BulkheadStats{
+			Route:    b.route,
+			Limit:    b.limit,
+			InFlight: len(b.slots),
+			Rejected: atomic.LoadInt64(&b.rejected),
+		})
+	}
+
+	sort.Slice(stats.Dependencies, func(i, j int) bool { return stats.Dependencies[i].Name < stats.Dependencies[j].Name })
+	sort.Slice(stats.Bulkheads, func(i, j int) bool { return stats.Bulkheads[i].Route < stats.Bulkheads[j].Route })
+	return stats
+}
@@ -0,0 +1,119 @@
+package Middleware
+
+import (
+	"cloud-platform-api/app/Config"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SecurityHeadersMiddleware 安全响应头中间件
+// 功能说明：
+// 1. 按配置设置 Content-Security-Policy（或 Content-Security-Policy-Report-Only）、X-Frame-Options、Referrer-Policy、Permissions-Policy
+// 2. 配置了CSP报告地址时在策略末尾追加 report-uri，浏览器将违规报告发送到 /api/v1/csp-report
+// 3. Strict-Transport-Security 只在HTTPS请求上设置，包括反向代理设置 X-Forwarded-Proto: https 的请求
+// 4. 所有响应设置 X-Content-Type-Options: nosniff
+//
+// 注意事项：
+// - 响应头在创建时按配置计算，修改配置后需要重启服务
+type SecurityHeadersMiddleware struct {
+	BaseMiddleware
+	enabled     bool
+	cspHeader   string
+	csp         string
+	hsts        string
+	frame       string
+	referrer    string
+	permissions string
+}
+
+// NewSecurityHeadersMiddleware 创建安全响应头中间件，config 为空时使用默认安全配置
+func NewSecurityHeadersMiddleware(config *Config.SecurityConfig) *SecurityHeadersMiddleware {
+	if config == nil {
+		config = &Config.SecurityConfig{}
+		config.SetDefaults()
+	}
+	headers := config.Headers
+	m := &SecurityHeadersMiddleware{
+		enabled:     headers.Enabled,
+		cspHeader:   "Content-Security-Policy",
+		frame:       strings.ToUpper(headers.FrameOptions),
+		referrer:    headers.ReferrerPolicy,
+		permissions: headers.PermissionsPolicy,
+	}
+	if headers.CSPReportOnly {
+		m.cspHeader = "Content-Security-Policy-Report-Only"
+	}
+	if config.ThreatProtection.ContentSecurityPolicy {
+		m.csp = BuildContentSecurityPolicy(config.ThreatProtection.CSPDirectives, headers.CSPReportURI)
+	}
+	if headers.HSTSMaxAge > 0 {
+		m.hsts = fmt.Sprintf("max-age=%d", int64(headers.HSTSMaxAge.Seconds()))
+		if headers.HSTSIncludeSubdomains {
+			m.hsts += "; includeSubDomains"
+		}
+		if headers.HSTSPreload {
+			m.hsts += "; preload"
+		}
+	}
+	return m
+}
+
+// BuildContentSecurityPolicy 规范化CSP指令，reportURI 不为空且指令中没有 report-uri 时追加
+func BuildContentSecurityPolicy(directives, reportURI string) string {
+	var parts []string
+	hasReportURI := false
+	for _, directive := range strings.Split(directives, ";") {
+		directive = strings.Join(strings.Fields(directive), " ")
+		if directive == "" {
+			continue
+		}
+		if strings.HasPrefix(strings.ToLower(directive), "report-uri ") {
+			hasReportURI = true
+		}
+		parts = append(parts, directive)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	if reportURI != "" && !hasReportURI {
+		parts = append(parts, "report-uri "+reportURI)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Handle 设置安全响应头
+func (m *SecurityHeadersMiddleware) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.enabled {
+			c.Next()
+			return
+		}
+		if m.csp != "" {
+			c.Header(m.cspHeader, m.csp)
+		}
+		if m.hsts != "" && isHTTPSRequest(c) {
+			c.Header("Strict-Transport-Security", m.hsts)
+		}
+		if m.frame != "" {
+			c.Header("X-Frame-Options", m.frame)
+		}
+		if m.referrer != "" {
+			c.Header("Referrer-Policy", m.referrer)
+		}
+		if m.permissions != "" {
+			c.Header("Permissions-Policy", m.permissions)
+		}
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Next()
+	}
+}
+
+// isHTTPSRequest 检查请求是否通过HTTPS到达，反向代理终止TLS时使用 X-Forwarded-Proto
+func isHTTPSRequest(c *gin.Context) bool {
+	if c.Request.TLS != nil {
+		return true
+	}
+	return strings.EqualFold(strings.TrimSpace(strings.Split(c.GetHeader("X-Forwarded-Proto"), ",")[0]), "https")
+}
@@ -53,6 +53,17 @@ func (m *SecurityMiddleware) SecurityCheck() gin.HandlerFunc {
 			}
 		}
 
+		// 自动响应封禁的IP直接拒绝
+		if m.securityService != nil && m.securityService.IsIPBlocked(c.Request.Context(), ipAddress) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "访问被拒绝",
+				"message": "IP地址已被封禁",
+				"code":    403,
+			})
+			c.Abort()
+			return
+		}
+
 		// 威胁防护检查
 		if m.securityService != nil {
 			allowed, reason := m.securityService.CheckThreatProtection(ipAddress, "", "")
@@ -69,10 +80,11 @@ func (m *SecurityMiddleware) SecurityCheck() gin.HandlerFunc {
 
 		// 异常检测
 		if m.securityService != nil && userIDUint > 0 {
-			isAnomaly, score := m.securityService.DetectAnomaly(userIDUint, "http_request", path, method, ipAddress, userAgent)
+			isAnomaly, score := m.securityService.DetectAnomaly(c.Request.Context(), userIDUint, "http_request", path, method, ipAddress, userAgent)
 			if isAnomaly {
 				// 记录异常事件
 				m.securityService.RecordSecurityEvent(
+					c.Request.Context(),
 					userIDUint,
 					"anomaly_detected",
 					"high",
@@ -89,14 +101,22 @@ func (m *SecurityMiddleware) SecurityCheck() gin.HandlerFunc {
 					"",
 				)
 
-				// 如果配置了自动阻止，则阻止请求
-				// 这里可以根据配置决定是否阻止
+				// 自动响应剧本封禁了来源IP时阻止本次请求
+				if m.securityService.IsIPBlocked(c.Request.Context(), ipAddress) {
+					c.JSON(http.StatusForbidden, gin.H{
+						"error":   "访问被拒绝",
+						"message": "检测到异常行为，IP地址已被封禁",
+						"code":    403,
+					})
+					c.Abort()
+					return
+				}
 			}
 		}
 
 		// 访问控制检查
 		if m.securityService != nil && userIDUint > 0 {
-			allowed, reason := m.securityService.CheckAccessControl(userIDUint, path, method)
+			allowed, reason := m.securityService.CheckAccessControl(c.Request.Context(), userIDUint, path, method)
 			if !allowed {
 				c.JSON(http.StatusForbidden, gin.H{
 					"error":   "访问被拒绝",
@@ -111,6 +131,7 @@ func (m *SecurityMiddleware) SecurityCheck() gin.HandlerFunc {
 		// 记录安全事件
 		if m.securityService != nil && userIDUint > 0 {
 			m.securityService.RecordSecurityEvent(
+				c.Request.Context(),
 				userIDUint,
 				"http_request",
 				"info",
@@ -570,7 +591,9 @@ func (m *SecurityMiddleware) RequestLogging() gin.HandlerFunc {
 					eventLevel = "error"
 				}
 
+				// 请求超时或客户端断开后上下文已取消，响应事件仍需记录
 				m.securityService.RecordSecurityEvent(
+					context.WithoutCancel(c.Request.Context()),
 					id,
 					"http_response",
 					eventLevel,
@@ -601,7 +624,10 @@ func (m *SecurityMiddleware) ContextSecurity() gin.HandlerFunc {
 		c.Request = c.Request.WithContext(ctx)
 
 		// 添加安全相关的上下文信息
-		c.Set("request_id", m.generateRequestID())
+		// 请求ID中间件已设置时沿用
+		if c.GetString("request_id") == "" {
+			c.Set("request_id", m.generateRequestID())
+		}
 		c.Set("timestamp", time.Now().Unix())
 		c.Set("ip_address", c.ClientIP())
 
@@ -0,0 +1,40 @@
+package Middleware
+
+import (
+	"cloud-platform-api/app/Services"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StartupGateMiddleware 降级模式中间件
+type StartupGateMiddleware struct {
+	BaseMiddleware
+	orchestrator *Services.StartupOrchestrator
+}
+
+// NewStartupGateMiddleware 创建降级模式中间件
+// 功能说明：
+// 1. 降级模式启动后，依赖不可用期间相关路由直接返回503，不访问不可用的依赖
+// 2. 健康检查和 /readyz 不受影响，用于查看依赖状态
+// 3. 依赖恢复后自动放行
+func NewStartupGateMiddleware(orchestrator *Services.StartupOrchestrator) *StartupGateMiddleware {
+	return &StartupGateMiddleware{orchestrator: orchestrator}
+}
+
+// Handle 处理降级模式
+func (m *StartupGateMiddleware) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if dependency, blocked := m.orchestrator.BlockedBy(c.Request.URL.Path); blocked {
+			c.Header("Retry-After", "30")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"success": false,
+				"message": "Service degraded",
+				"error":   fmt.Sprintf("依赖 %s 暂不可用，请稍后重试", dependency),
+			})
+			return
+		}
+		c.Next()
+	}
+}
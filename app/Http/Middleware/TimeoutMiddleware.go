@@ -1,10 +1,13 @@
 package Middleware
 
 import (
+	"cloud-platform-api/app/Config"
 	"cloud-platform-api/app/Storage"
 	"context"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -26,84 +29,134 @@ func NewTimeoutMiddleware(storageManager *Storage.StorageManager) *TimeoutMiddle
 	}
 }
 
-// Handle 处理请求超时
-// 
+// Handle 为所有请求设置相同的超时，timeout 为0时不设置
+func (m *TimeoutMiddleware) Handle(timeout time.Duration) gin.HandlerFunc {
+	return m.handle(func(*gin.Context) time.Duration { return timeout })
+}
+
+// HandleGroups 按路由分组设置请求超时
+//
 // 功能说明：
-// 1. 为每个请求设置超时上下文，防止长时间运行的请求阻塞服务器
-// 2. 使用goroutine并发处理请求，通过select监听完成或超时
-// 3. 超时时返回408请求超时错误，并记录详细日志
-// 4. 确保context的cancel函数被调用，避免资源泄漏
+// 1. 请求路径匹配最长的分组前缀（按路径段匹配，/api/v1/auth 不匹配 /api/v1/authors），使用该分组的超时
+// 2. 没有匹配的分组时使用默认超时，超时为0时不设置截止时间
+// 3. config 为空时使用默认配置，未启用时不设置超时
+func (m *TimeoutMiddleware) HandleGroups(config *Config.RequestTimeoutConfig) gin.HandlerFunc {
+	if config == nil {
+		config = &Config.RequestTimeoutConfig{Enabled: true, Default: Config.DefaultRequestTimeout}
+	}
+	if !config.Enabled {
+		return func(c *gin.Context) { c.Next() }
+	}
+	// 配置加载时已验证，解析失败时只使用默认超时
+	groups, _ := config.ParseGroups()
+	return m.handle(func(c *gin.Context) time.Duration {
+		path := c.Request.URL.Path
+		for _, group := range groups {
+			if path == group.Prefix || strings.HasPrefix(path, group.Prefix+"/") {
+				return group.Timeout
+			}
+		}
+		return config.Default
+	})
+}
+
+// handle 处理请求超时
 //
 // 实现原理：
-// - 使用context.WithTimeout创建带超时的上下文
-// - 在独立的goroutine中执行请求处理（c.Next()）
-// - 使用select同时监听请求完成信号和超时信号
-// - 如果超时先发生，立即返回408错误并调用c.Abort()停止后续处理
+// - 使用context.WithTimeout创建带超时的上下文，后续中间件和处理器通过c.Request.Context()获取
+// - 服务层把上下文传给GORM（db.WithContext）和出站HTTP请求，超时后查询和请求随上下文取消，处理器随之返回
+// - 处理器在当前goroutine中执行，不会在超时后与中间件并发读写gin.Context
+// - 超时后处理器写入的响应被丢弃，统一返回408请求超时
 //
 // 注意事项：
-// - defer cancel()确保即使函数提前返回也会释放context资源
-// - done通道使用缓冲通道（容量1）避免goroutine阻塞
-// - 超时后goroutine可能仍在运行，但c.Abort()会阻止响应写入
+// - 不检查上下文的处理器会一直执行到完成，超时只影响返回的响应
+// - 客户端断开连接导致的取消不是超时，不返回408
 // - storageManager需要nil检查，避免在未初始化时panic
-func (m *TimeoutMiddleware) Handle(timeout time.Duration) gin.HandlerFunc {
+func (m *TimeoutMiddleware) handle(timeoutFor func(*gin.Context) time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 创建超时上下文
-		// 注意：context.WithTimeout会创建一个在指定时间后自动取消的context
-		// 必须调用cancel()释放资源，即使context已经超时
+		timeout := timeoutFor(c)
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
 		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
 		defer cancel() // 确保资源释放，避免context泄漏
-
-		// 将超时上下文设置到请求中
-		// 这样后续的中间件和处理器可以通过c.Request.Context()获取超时上下文
-		// 如果它们支持context取消，可以在超时时自动停止
 		c.Request = c.Request.WithContext(ctx)
 
-		// 创建完成通道（使用缓冲通道避免goroutine阻塞）
-		// 容量为1，确保即使没有接收者，发送操作也不会阻塞
-		done := make(chan bool, 1)
+		writer := &timeoutWriter{ResponseWriter: c.Writer, ctx: ctx}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
 
-		// 在独立的goroutine中处理请求
-		// 这样可以并发监听请求完成和超时两个事件
-		go func() {
-			// 处理请求（执行后续中间件和处理器）
-			// 注意：即使超时发生，这个goroutine可能仍在运行
-			// 但c.Abort()会阻止响应写入，客户端会收到超时错误
-			c.Next()
-			// 请求处理完成，发送完成信号
-			done <- true
-		}()
-
-		// 使用select同时监听两个事件：请求完成或超时
-		select {
-		case <-done:
-			// 请求正常完成（在超时之前）
-			// 正常返回，让响应继续发送给客户端
+		if !writer.timedOut && (writer.Written() || !errors.Is(ctx.Err(), context.DeadlineExceeded)) {
 			return
-		case <-ctx.Done():
-			// 请求超时（超时发生在请求完成之前）
-			// ctx.Done()会在超时时间到达时被关闭
-			
-			// 记录超时日志（需要nil检查，避免panic）
-			if m.storageManager != nil {
-				m.storageManager.LogWarning("请求超时", map[string]interface{}{
-					"url":        c.Request.URL.String(),
-					"method":     c.Request.Method,
-					"client_ip":  c.ClientIP(),
-					"timeout":    timeout.String(),
-					"user_agent": c.Request.UserAgent(),
-				})
-			}
+		}
 
-			// 返回超时错误响应
-			// 使用408 Request Timeout状态码，符合HTTP标准
-			c.JSON(http.StatusRequestTimeout, gin.H{
-				"success": false,
-				"message": "Request timeout",
-				"error":   "The request took too long to process",
+		// 记录超时日志（需要nil检查，避免panic）
+		if m.storageManager != nil {
+			m.storageManager.LogWarning("请求超时", map[string]interface{}{
+				"url":        c.Request.URL.String(),
+				"method":     c.Request.Method,
+				"client_ip":  c.ClientIP(),
+				"timeout":    timeout.String(),
+				"user_agent": c.Request.UserAgent(),
 			})
-			// 中止后续处理，防止重复响应
-			c.Abort()
-			return
 		}
+
+		// 清除处理器设置的内容相关响应头，避免与超时响应不一致
+		header := c.Writer.Header()
+		header.Del("Content-Type")
+		header.Del("Content-Length")
+		header.Del("Content-Disposition")
+
+		// 使用408 Request Timeout状态码，与错误处理中间件对超时错误的处理一致
+		c.JSON(http.StatusRequestTimeout, gin.H{
+			"success": false,
+			"message": "Request timeout",
+			"error":   "The request took too long to process",
+		})
+		c.Abort()
+	}
+}
+
+// timeoutWriter 超时后丢弃处理器写入的响应
+type timeoutWriter struct {
+	gin.ResponseWriter
+	ctx      context.Context
+	timedOut bool
+}
+
+// expired 响应还未写入且已经超时时返回 true，之后的写入全部丢弃
+func (w *timeoutWriter) expired() bool {
+	if !w.timedOut && !w.ResponseWriter.Written() && errors.Is(w.ctx.Err(), context.DeadlineExceeded) {
+		w.timedOut = true
+	}
+	return w.timedOut
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	if !w.expired() {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	if w.expired() {
+		return 0, w.ctx.Err()
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	if w.expired() {
+		return 0, w.ctx.Err()
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *timeoutWriter) Flush() {
+	if !w.expired() {
+		w.ResponseWriter.Flush()
 	}
 }
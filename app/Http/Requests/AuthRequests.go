@@ -74,6 +74,8 @@ type UpdateProfileRequest struct {
 	Username string `json:"username" binding:"omitempty,min=3,max=50"`
 	Email    string `json:"email" binding:"omitempty,email"`
 	Avatar   string `json:"avatar" binding:"omitempty,url"`
+	Locale   string `json:"locale" binding:"omitempty,max=20"` // 偏好语言，如 zh-CN、en
+	Version  uint   `json:"version"`                           // 期望的资料版本，可用 If-Match 请求头代替
 }
 
 // Validate 验证更新资料请求
@@ -0,0 +1,51 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Storage"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterApprovalRoutes 注册敏感操作审批路由，所有路由需要管理员权限
+func RegisterApprovalRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.ApprovalController) {
+	approvalGroup := router.Group("/api/v1/admin/approvals")
+	approvalGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	approvalGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+
+	idParam := OpenAPI.PathID("id", "审批记录ID")
+	api := OpenAPI.DefaultRegistry().Group(approvalGroup, "操作审批", OpenAPI.BearerAuth)
+	{
+		api.GET("", OpenAPI.Route{
+			Summary:     "获取审批记录列表",
+			Description: "查询前将超过审批或执行期限的记录标记为过期，filter[status][eq]=pending 查询待审批的操作",
+			List:        &Controllers.ApprovalQuerySpec,
+		}, controller.GetApprovals)
+		api.GET("/:id", OpenAPI.Route{
+			Summary:     "获取审批记录",
+			Description: "包含待执行请求的方法、路径和请求体",
+			Params:      []OpenAPI.Param{idParam},
+			Response:    Models.AdminApproval{},
+			Errors:      []int{http.StatusNotFound},
+		}, controller.GetApproval)
+		api.POST("/:id/approve", OpenAPI.Route{
+			Summary:     "审批通过",
+			Description: "需要发起人以外的管理员审批；通过后发起人在执行期限内携带 X-Approval-ID 请求头重新提交原请求",
+			Params:      []OpenAPI.Param{idParam},
+			Request:     Controllers.ReviewApprovalRequest{},
+			Response:    Models.AdminApproval{},
+			Errors:      []int{http.StatusForbidden, http.StatusNotFound, http.StatusConflict},
+		}, controller.ApproveApproval)
+		api.POST("/:id/reject", OpenAPI.Route{
+			Summary:  "拒绝审批",
+			Params:   []OpenAPI.Param{idParam},
+			Request:  Controllers.ReviewApprovalRequest{},
+			Response: Models.AdminApproval{},
+			Errors:   []int{http.StatusForbidden, http.StatusNotFound, http.StatusConflict},
+		}, controller.RejectApproval)
+	}
+}
@@ -0,0 +1,21 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterArchiveRoutes 注册大表分区和归档路由，所有路由需要管理员权限
+func RegisterArchiveRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.ArchiveController) {
+	archiveGroup := router.Group("/api/v1/archive")
+	archiveGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	archiveGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+	{
+		archiveGroup.GET("/partitions", controller.GetPartitions)
+		archiveGroup.POST("/run", controller.RunMaintenance)
+		archiveGroup.GET("/tables/:table/rows", controller.QueryTable)
+	}
+}
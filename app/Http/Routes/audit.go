@@ -0,0 +1,27 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterAuditRoutes 注册审计日志查询路由，需要管理员权限
+func RegisterAuditRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.AuditLogController) {
+	auditGroup := router.Group("/api/v1/admin/audit-logs")
+	auditGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	auditGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+
+	api := OpenAPI.DefaultRegistry().Group(auditGroup, "审计日志", OpenAPI.BearerAuth)
+	{
+		api.GET("", OpenAPI.Route{
+			Summary:  "获取审计日志列表",
+			List:     &Controllers.AuditLogQuerySpec,
+			Response: Models.AuditLog{},
+		}, controller.GetAuditLogs)
+	}
+}
@@ -0,0 +1,53 @@
+package Routes
+
+import "sync"
+
+// BackgroundServices 路由注册时创建的后台服务启动步骤
+// 功能说明：
+// 1. 路由注册只创建服务实例并登记启动步骤，不启动后台协程、不注册GORM回调
+// 2. 应用启动时调用 Start 按登记顺序执行一次，重复构建路由（如测试）不会产生副作用
+// 3. 每个步骤自行记录启动失败日志，单个服务启动失败不影响其他服务
+type BackgroundServices struct {
+	mu      sync.Mutex
+	steps   []backgroundStep
+	started bool
+}
+
+type backgroundStep struct {
+	name  string
+	start func()
+}
+
+// add 登记启动步骤
+func (b *BackgroundServices) add(name string, start func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.steps = append(b.steps, backgroundStep{name: name, start: start})
+}
+
+// Names 按登记顺序返回启动步骤名称
+func (b *BackgroundServices) Names() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	names := make([]string, 0, len(b.steps))
+	for _, step := range b.steps {
+		names = append(names, step.name)
+	}
+	return names
+}
+
+// Start 按登记顺序执行全部启动步骤，重复调用只执行一次
+func (b *BackgroundServices) Start() {
+	b.mu.Lock()
+	if b.started {
+		b.mu.Unlock()
+		return
+	}
+	b.started = true
+	steps := append([]backgroundStep(nil), b.steps...)
+	b.mu.Unlock()
+
+	for _, step := range steps {
+		step.start()
+	}
+}
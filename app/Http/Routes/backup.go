@@ -0,0 +1,110 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterBackupRoutes 注册备份管理路由，所有路由需要管理员权限
+func RegisterBackupRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.BackupController) {
+	backupGroup := router.Group("/api/v1/admin/backups")
+	backupGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	backupGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+
+	idParam := OpenAPI.Param{Name: "id", Description: "备份ID或文件名"}
+	api := OpenAPI.DefaultRegistry().Group(backupGroup, "备份管理", OpenAPI.BearerAuth)
+	{
+		api.GET("", OpenAPI.Route{
+			Summary:     "获取备份列表",
+			Description: "包含每个备份的校验和、加密信息和清单",
+			Response:    []Services.BackupInfo{},
+		}, controller.GetBackups)
+		api.GET("/domains", OpenAPI.Route{
+			Summary:     "获取备份领域",
+			Description: "返回每个逻辑领域在当前数据库中包含的表",
+		}, controller.GetBackupDomains)
+		api.POST("/tables", OpenAPI.Route{
+			Summary:     "按表或领域创建备份",
+			Description: "各表在同一只读事务中导出为JSON Lines，压缩包内附清单",
+			Request:     Services.BackupSelection{},
+			Response:    Services.BackupInfo{},
+			Status:      http.StatusCreated,
+		}, controller.CreateTableBackup)
+		api.GET("/:id/manifest", OpenAPI.Route{
+			Summary:  "获取备份清单",
+			Params:   []OpenAPI.Param{idParam},
+			Response: Services.BackupManifest{},
+			Errors:   []int{http.StatusNotFound},
+		}, controller.GetBackupManifest)
+		api.POST("/:id/restore", OpenAPI.Route{
+			Summary:     "从备份中恢复选择的表",
+			Description: "选择的表在一个事务中清空并写入备份数据，其他表不受影响；数据库备份和完整备份必须选择表或领域，需设置 confirm 为 true。启用双人审批时先返回202和待审批记录，审批通过后携带 X-Approval-ID 重新提交",
+			Params:      []OpenAPI.Param{idParam},
+			Request:     Controllers.BackupRestoreRequest{},
+			Response:    Services.SelectiveRestoreResult{},
+			Errors:      []int{http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+		}, Middleware.NewApprovalMiddleware(nil).Require(Config.ApprovalActionBackupRestore), controller.RestoreBackupTables)
+
+		scheduleIDParam := OpenAPI.PathID("id", "定时备份ID")
+		api.GET("/schedules", OpenAPI.Route{
+			Summary:     "获取定时备份列表",
+			Description: "包含下次运行时间、上次运行结果和连续失败次数",
+			Response:    []Models.BackupSchedule{},
+		}, controller.GetBackupSchedules)
+		api.POST("/schedules", OpenAPI.Route{
+			Summary:     "创建定时备份",
+			Description: "type 为 database、files、full 或 tables，tables 类型需要选择表或领域；target 为 local 或 archive（另外上传到归档存储）",
+			Request:     Services.BackupScheduleInput{},
+			Response:    Models.BackupSchedule{},
+			Status:      http.StatusCreated,
+		}, controller.CreateBackupSchedule)
+		api.GET("/schedules/:id", OpenAPI.Route{
+			Summary:  "获取定时备份",
+			Params:   []OpenAPI.Param{scheduleIDParam},
+			Response: Models.BackupSchedule{},
+			Errors:   []int{http.StatusNotFound},
+		}, controller.GetBackupSchedule)
+		api.PUT("/schedules/:id", OpenAPI.Route{
+			Summary:  "更新定时备份",
+			Params:   []OpenAPI.Param{scheduleIDParam},
+			Request:  Services.BackupScheduleInput{},
+			Response: Models.BackupSchedule{},
+			Errors:   []int{http.StatusNotFound},
+		}, controller.UpdateBackupSchedule)
+		api.DELETE("/schedules/:id", OpenAPI.Route{
+			Summary:     "删除定时备份",
+			Description: "同时删除运行记录，已创建的备份文件保留",
+			Params:      []OpenAPI.Param{scheduleIDParam},
+			Errors:      []int{http.StatusNotFound},
+		}, controller.DeleteBackupSchedule)
+		api.POST("/schedules/:id/run", OpenAPI.Route{
+			Summary:     "手动触发定时备份",
+			Description: "备份在后台执行，返回运行记录，通过 /runs/{id} 轮询进度",
+			Params:      []OpenAPI.Param{scheduleIDParam},
+			Response:    Models.BackupRun{},
+			Errors:      []int{http.StatusNotFound, http.StatusConflict},
+		}, controller.RunBackupSchedule)
+		api.GET("/schedules/:id/runs", OpenAPI.Route{
+			Summary:  "获取定时备份的运行记录",
+			Params:   []OpenAPI.Param{scheduleIDParam},
+			Query:    Controllers.BackupRunQuery{},
+			Response: []Models.BackupRun{},
+			Errors:   []int{http.StatusNotFound},
+		}, controller.GetBackupScheduleRuns)
+		api.GET("/runs/:id", OpenAPI.Route{
+			Summary:     "获取备份运行记录",
+			Description: "返回运行状态、阶段和进度",
+			Params:      []OpenAPI.Param{OpenAPI.PathID("id", "运行记录ID")},
+			Response:    Models.BackupRun{},
+			Errors:      []int{http.StatusNotFound},
+		}, controller.GetBackupRun)
+	}
+}
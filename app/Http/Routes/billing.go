@@ -0,0 +1,38 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterBillingRoutes 注册计费路由
+func RegisterBillingRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.BillingController) {
+	authMiddleware := Middleware.NewAuthMiddleware()
+
+	// Stripe Webhook，使用签名校验，不需要认证
+	router.POST("/api/v1/billing/stripe/webhook", controller.StripeWebhook)
+
+	// 套餐和当前用户的订阅，需要认证
+	billingGroup := router.Group("/api/v1/billing")
+	billingGroup.Use(authMiddleware.Handle())
+	{
+		billingGroup.GET("/plans", controller.GetPlans)
+		billingGroup.GET("/subscription", controller.GetSubscription)
+		billingGroup.POST("/checkout", controller.Checkout)
+		billingGroup.POST("/subscription/preview", controller.PreviewChange)
+		billingGroup.PUT("/subscription", controller.ChangePlan)
+		billingGroup.DELETE("/subscription", controller.CancelSubscription)
+	}
+
+	// 套餐管理，需要管理员权限
+	adminGroup := router.Group("/api/v1/admin/billing")
+	adminGroup.Use(authMiddleware.Handle())
+	adminGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+	{
+		adminGroup.GET("/plans", controller.GetAllPlans)
+		adminGroup.PUT("/plans", controller.SavePlan)
+	}
+}
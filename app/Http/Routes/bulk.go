@@ -0,0 +1,27 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterBulkRoutes 注册用户和角色批量操作路由，所有路由需要管理员权限
+func RegisterBulkRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.BulkController) {
+	bulkGroup := router.Group("/api/v1/admin/bulk")
+	bulkGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	bulkGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+	{
+		bulkGroup.POST("/users/import", controller.ImportUsers)
+		bulkGroup.POST("/users/roles", controller.AssignRoles)
+		bulkGroup.POST("/users/disable", controller.DisableUsers)
+		bulkGroup.POST("/users/enable", controller.EnableUsers)
+		bulkGroup.POST("/users/delete", controller.DeleteUsers)
+
+		bulkGroup.GET("/jobs", controller.GetJobs)
+		bulkGroup.GET("/jobs/:id", controller.GetJob)
+		bulkGroup.GET("/jobs/:id/errors", controller.GetJobErrors)
+	}
+}
@@ -0,0 +1,45 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Storage"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterCleanupRoutes 注册过期数据清理任务管理路由，所有路由需要管理员权限
+func RegisterCleanupRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.CleanupController) {
+	cleanupGroup := router.Group("/api/v1/admin/cleanup")
+	cleanupGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	cleanupGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+
+	jobParam := OpenAPI.Param{Name: "job", Description: "清理任务名称"}
+	api := OpenAPI.DefaultRegistry().Group(cleanupGroup, "数据清理", OpenAPI.BearerAuth)
+	{
+		api.GET("", OpenAPI.Route{
+			Summary:     "获取清理任务列表",
+			Description: "列出已注册的清理任务及最近一次运行的进度、删除行数和结果",
+		}, controller.GetCleanupJobs)
+		api.GET("/:job", OpenAPI.Route{
+			Summary: "获取清理任务状态",
+			Params:  []OpenAPI.Param{jobParam},
+			Errors:  []int{http.StatusNotFound},
+		}, controller.GetCleanupJob)
+		api.POST("/:job/run", OpenAPI.Route{
+			Summary:     "立即运行清理任务",
+			Description: "在后台运行清理任务，通过任务状态查询进度；任务正在运行时返回409",
+			Params:      []OpenAPI.Param{jobParam},
+			Status:      http.StatusAccepted,
+			Errors:      []int{http.StatusNotFound, http.StatusConflict},
+		}, controller.RunCleanupJob)
+		api.POST("/:job/cancel", OpenAPI.Route{
+			Summary:     "取消正在运行的清理任务",
+			Description: "当前批次完成后停止，已删除的批次不回滚；任务未在运行时返回409",
+			Params:      []OpenAPI.Param{jobParam},
+			Errors:      []int{http.StatusNotFound, http.StatusConflict},
+		}, controller.CancelCleanupJob)
+	}
+}
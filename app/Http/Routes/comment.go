@@ -0,0 +1,21 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterCommentRoutes 注册评论路由
+// 所有接口需要认证，监控报告评论的管理员权限和编辑删除权限在服务中检查
+func RegisterCommentRoutes(router *gin.Engine, controller *Controllers.CommentController) {
+	commentGroup := router.Group("/api/v1/comments")
+	commentGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	{
+		commentGroup.GET("", controller.ListComments)
+		commentGroup.POST("", controller.CreateComment)
+		commentGroup.PUT("/:id", controller.UpdateComment)
+		commentGroup.DELETE("/:id", controller.DeleteComment)
+	}
+}
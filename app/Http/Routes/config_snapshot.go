@@ -0,0 +1,68 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterConfigSnapshotRoutes 注册配置快照路由，所有路由需要管理员权限
+func RegisterConfigSnapshotRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.ConfigSnapshotController) {
+	configGroup := router.Group("/api/v1/admin/config")
+	configGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	configGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+
+	idParam := OpenAPI.PathID("id", "快照ID")
+	api := OpenAPI.DefaultRegistry().Group(configGroup, "配置管理", OpenAPI.BearerAuth)
+	{
+		api.GET("/effective", OpenAPI.Route{
+			Summary:     "获取当前有效配置",
+			Description: "配置展开为 section.key 形式，密码、密钥、令牌和URL中的密码已脱敏",
+			Errors:      []int{http.StatusServiceUnavailable},
+		}, controller.GetEffectiveConfig)
+		api.GET("/snapshots", OpenAPI.Route{
+			Summary:  "获取配置快照列表",
+			Query:    Controllers.ConfigSnapshotQuery{},
+			Response: []Models.ConfigSnapshot{},
+		}, controller.GetConfigSnapshots)
+		api.POST("/snapshots", OpenAPI.Route{
+			Summary:     "为当前配置生成快照",
+			Description: "版本号在同一环境内递增，environment 为空时使用 APP_ENV",
+			Request:     Services.ConfigSnapshotInput{},
+			Response:    Models.ConfigSnapshot{},
+			Status:      http.StatusCreated,
+		}, controller.CreateConfigSnapshot)
+		api.POST("/snapshots/import", OpenAPI.Route{
+			Summary:     "导入其他环境的配置快照",
+			Description: "values 为其他环境有效配置或快照详情返回的配置，导入时再次脱敏",
+			Request:     Services.ConfigSnapshotInput{},
+			Response:    Models.ConfigSnapshot{},
+			Status:      http.StatusCreated,
+		}, controller.ImportConfigSnapshot)
+		api.GET("/snapshots/:id", OpenAPI.Route{
+			Summary:  "获取配置快照",
+			Params:   []OpenAPI.Param{idParam},
+			Response: Models.ConfigSnapshot{},
+			Errors:   []int{http.StatusNotFound},
+		}, controller.GetConfigSnapshot)
+		api.DELETE("/snapshots/:id", OpenAPI.Route{
+			Summary: "删除配置快照",
+			Params:  []OpenAPI.Param{idParam},
+			Errors:  []int{http.StatusNotFound},
+		}, controller.DeleteConfigSnapshot)
+		api.GET("/snapshots/:id/diff", OpenAPI.Route{
+			Summary:     "比较配置快照",
+			Description: "与 to 指定的快照比较，to 为空时与当前配置比较；敏感配置只比较是否设置",
+			Params:      []OpenAPI.Param{idParam},
+			Query:       Controllers.ConfigDiffQuery{},
+			Response:    Services.ConfigDiff{},
+			Errors:      []int{http.StatusNotFound},
+		}, controller.DiffConfigSnapshot)
+	}
+}
@@ -0,0 +1,23 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/OpenAPI"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterCSPReportRoutes 注册CSP违规报告路由，浏览器上报时不携带认证信息，路由不需要认证
+func RegisterCSPReportRoutes(router *gin.Engine, controller *Controllers.CSPReportController) {
+	reportGroup := router.Group("/api/v1")
+	api := OpenAPI.DefaultRegistry().Group(reportGroup, "安全防护")
+	{
+		api.POST("/csp-report", OpenAPI.Route{
+			Summary:     "接收CSP违规报告",
+			Description: "浏览器按 Content-Security-Policy 的 report-uri 指令自动上报，兼容 application/csp-report 和 application/reports+json，报告保存为 csp_violation 类型的安全事件",
+			Status:      http.StatusNoContent,
+			Errors:      []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge},
+		}, controller.ReportCSPViolation)
+	}
+}
@@ -0,0 +1,38 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterDebugRoutes 注册请求调试记录路由，所有路由需要管理员权限
+func RegisterDebugRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.DebugController) {
+	debugGroup := router.Group(Services.DebugRequestAdminPath)
+	debugGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	debugGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+
+	api := OpenAPI.DefaultRegistry().Group(debugGroup, "请求调试", OpenAPI.BearerAuth)
+	{
+		api.GET("", OpenAPI.Route{
+			Summary:  "获取调试记录摘要",
+			Response: []Services.DebugRequestSummary{},
+		}, controller.ListRequests)
+		api.DELETE("", OpenAPI.Route{
+			Summary:  "清空调试记录",
+			Response: Controllers.DebugClearResponse{},
+		}, controller.ClearRequests)
+		api.GET("/:request_id", OpenAPI.Route{
+			Summary:     "获取请求的调试记录",
+			Description: "管理员请求携带调试请求头（默认 X-Debug: 1）时记录，响应头 X-Debug-Id 为请求ID",
+			Params:      []OpenAPI.Param{{Name: "request_id", Description: "请求ID"}},
+			Response:    Services.DebugRequest{},
+			Errors:      []int{http.StatusNotFound},
+		}, controller.GetRequest)
+	}
+}
@@ -0,0 +1,38 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Storage"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterEventBusRoutes 注册领域事件总线管理路由，所有路由需要管理员权限
+func RegisterEventBusRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.EventBusController) {
+	eventGroup := router.Group("/api/v1/admin/events")
+	eventGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	eventGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+
+	api := OpenAPI.DefaultRegistry().Group(eventGroup, "事件总线", OpenAPI.BearerAuth)
+	{
+		api.GET("", OpenAPI.Route{
+			Summary:  "获取领域事件列表",
+			List:     &Controllers.OutboxEventQuerySpec,
+			Response: Models.OutboxEvent{},
+		}, controller.GetEvents)
+		api.GET("/stats", OpenAPI.Route{
+			Summary:     "获取事件总线状态",
+			Description: "返回各状态的事件数、启用的外部消息系统和进程内订阅",
+		}, controller.GetStats)
+		api.POST("/:id/retry", OpenAPI.Route{
+			Summary:     "重新投递失败的事件",
+			Description: "将失败的事件重置为待投递，已成功投递的目标不会重复投递",
+			Params:      []OpenAPI.Param{OpenAPI.PathID("id", "事件ID")},
+			Errors:      []int{http.StatusNotFound},
+		}, controller.RetryEvent)
+	}
+}
@@ -0,0 +1,30 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterExportRoutes 注册数据导出路由
+// 导出接口与对应的列表接口权限相同，只需要认证；定时导出需要管理员权限
+func RegisterExportRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.ExportController) {
+	auth := Middleware.NewAuthMiddleware().Handle()
+	router.GET("/api/v1/monitoring/alerts/export", auth, controller.ExportAlerts)
+	router.GET("/api/v1/security/events/export", auth, controller.ExportSecurityEvents)
+	router.GET("/api/v1/security/login-attempts/export", auth, controller.ExportLoginAttempts)
+
+	scheduleGroup := router.Group("/api/v1/exports/schedules")
+	scheduleGroup.Use(auth)
+	scheduleGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+	{
+		scheduleGroup.GET("", controller.GetExportSchedules)
+		scheduleGroup.POST("", controller.CreateExportSchedule)
+		scheduleGroup.GET("/:id", controller.GetExportSchedule)
+		scheduleGroup.PUT("/:id", controller.UpdateExportSchedule)
+		scheduleGroup.DELETE("/:id", controller.DeleteExportSchedule)
+		scheduleGroup.POST("/:id/run", controller.RunExportSchedule)
+	}
+}
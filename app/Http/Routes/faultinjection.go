@@ -0,0 +1,43 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterFaultInjectionRoutes 注册故障注入管理路由，所有路由需要管理员权限
+func RegisterFaultInjectionRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.FaultInjectionController) {
+	faultGroup := router.Group(Services.FaultInjectionAdminPath)
+	faultGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	faultGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+
+	api := OpenAPI.DefaultRegistry().Group(faultGroup, "故障注入", OpenAPI.BearerAuth)
+	{
+		api.GET("", OpenAPI.Route{
+			Summary:  "获取生效中的故障注入",
+			Response: []Services.FaultInjection{},
+		}, controller.GetFaults)
+		api.POST("", OpenAPI.Route{
+			Summary:     "创建故障注入",
+			Description: "path 和 service 只能指定一个；到期自动失效，duration_seconds 为0时使用默认有效期",
+			Request:     Services.FaultInjectionInput{},
+			Response:    Services.FaultInjection{},
+			Status:      http.StatusCreated,
+		}, controller.CreateFault)
+		api.DELETE("", OpenAPI.Route{
+			Summary:  "撤销全部故障注入",
+			Response: Controllers.FaultInjectionClearResponse{},
+		}, controller.ClearFaults)
+		api.DELETE("/:id", OpenAPI.Route{
+			Summary: "撤销故障注入",
+			Params:  []OpenAPI.Param{{Name: "id", Description: "故障注入ID"}},
+			Errors:  []int{http.StatusNotFound},
+		}, controller.DeleteFault)
+	}
+}
@@ -0,0 +1,42 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterImpersonationRoutes 注册管理员模拟登录路由，所有路由需要管理员权限
+func RegisterImpersonationRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.ImpersonationController) {
+	impersonationGroup := router.Group("/api/v1/admin/impersonations")
+	impersonationGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	impersonationGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+
+	api := OpenAPI.DefaultRegistry().Group(impersonationGroup, "模拟登录", OpenAPI.BearerAuth)
+	{
+		api.POST("", OpenAPI.Route{
+			Summary:     "发起模拟登录",
+			Description: "以目标用户身份签发限时模拟令牌；模拟期间每个请求记录审计日志，修改密码、创建API密钥等敏感操作返回403，被模拟的用户会收到站内通知",
+			Request:     Controllers.StartImpersonationRequest{},
+			Response:    Services.ImpersonationResult{},
+			Status:      http.StatusCreated,
+			Errors:      []int{http.StatusForbidden, http.StatusNotFound},
+		}, controller.StartImpersonation)
+		api.GET("", OpenAPI.Route{
+			Summary: "获取模拟会话列表",
+			List:    &Controllers.ImpersonationQuerySpec,
+			Query:   Controllers.ImpersonationListQuery{},
+		}, controller.GetImpersonations)
+		api.DELETE("/:id", OpenAPI.Route{
+			Summary:     "结束模拟会话",
+			Description: "会话的模拟令牌立即失效",
+			Params:      []OpenAPI.Param{OpenAPI.PathID("id", "模拟会话ID")},
+			Errors:      []int{http.StatusNotFound, http.StatusConflict},
+		}, controller.EndImpersonation)
+	}
+}
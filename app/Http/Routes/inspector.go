@@ -0,0 +1,44 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterInspectorRoutes 注册请求检查器路由，所有路由需要管理员权限
+func RegisterInspectorRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.InspectorController) {
+	inspectorGroup := router.Group("/api/v1/admin/inspector")
+	inspectorGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	inspectorGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+
+	api := OpenAPI.DefaultRegistry().Group(inspectorGroup, "请求检查器", OpenAPI.BearerAuth)
+	{
+		api.GET("/requests", OpenAPI.Route{
+			Summary:     "获取检查器记录列表",
+			Description: "支持 filter[status_code][gte]=500、filter[has_exception]=true、filter[user_id]=1 等筛选",
+			Response:    []Models.InspectorEntry{},
+		}, controller.ListRequests)
+		api.DELETE("/requests", OpenAPI.Route{
+			Summary:  "清空检查器记录",
+			Response: Controllers.InspectorClearResponse{},
+		}, controller.ClearRequests)
+		api.GET("/requests/:id", OpenAPI.Route{
+			Summary:     "获取检查器记录详情",
+			Description: "包含请求头、请求体、响应头、响应体、异常，以及请求执行的SQL、缓存操作、出站HTTP调用和通知",
+			Params:      []OpenAPI.Param{{Name: "id", Description: "记录ID"}},
+			Response:    Services.InspectorEntryDetail{},
+			Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+		}, controller.GetRequest)
+		api.GET("/stats", OpenAPI.Route{
+			Summary:  "获取请求检查器统计",
+			Response: Services.InspectorStats{},
+		}, controller.GetStats)
+	}
+}
@@ -0,0 +1,29 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterMailRoutes 注册邮件投递管理路由
+func RegisterMailRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.MailController) {
+	// 服务商退信回调，由服务商调用，通过 token 参数校验，不需要认证
+	router.POST("/api/v1/mail/webhooks/:provider", controller.HandleWebhook)
+
+	// 投递记录和屏蔽列表管理，需要管理员权限
+	mailGroup := router.Group("/api/v1/mail")
+	mailGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	mailGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+	{
+		mailGroup.GET("/messages", controller.GetMessages)
+		mailGroup.GET("/messages/:id", controller.GetMessage)
+		mailGroup.POST("/messages/:id/retry", controller.RetryMessage)
+
+		mailGroup.GET("/suppressions", controller.GetSuppressions)
+		mailGroup.POST("/suppressions", controller.AddSuppression)
+		mailGroup.DELETE("/suppressions/:email", controller.RemoveSuppression)
+	}
+}
@@ -1,8 +1,10 @@
 package Routes
 
 import (
+	"cloud-platform-api/app/Config"
 	"cloud-platform-api/app/Http/Controllers"
 	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Storage"
 
 	"github.com/gin-gonic/gin"
 )
@@ -26,11 +28,14 @@ func RegisterMonitoringRoutes(router *gin.Engine, controller *Controllers.Monito
 		monitoringGroup.POST("/alerts/:id/acknowledge", controller.AcknowledgeAlert)
 		monitoringGroup.POST("/alerts/:id/resolve", controller.ResolveAlert)
 
-		// 告警规则相关路由
+		// 实时推送指标和告警状态变化（SSE）
+		monitoringGroup.GET("/stream", Middleware.SkipBodyLogging(), controller.StreamEvents)
+
+		// 告警规则相关路由，删除需要双人审批
 		monitoringGroup.GET("/alert-rules", controller.GetAlertRules)
 		monitoringGroup.POST("/alert-rules", controller.CreateAlertRule)
 		monitoringGroup.PUT("/alert-rules/:id", controller.UpdateAlertRule)
-		monitoringGroup.DELETE("/alert-rules/:id", controller.DeleteAlertRule)
+		monitoringGroup.DELETE("/alert-rules/:id", Middleware.NewApprovalMiddleware(nil).Require(Config.ApprovalActionAlertRuleDelete), controller.DeleteAlertRule)
 
 		// 系统健康状态
 		monitoringGroup.GET("/health", controller.GetSystemHealth)
@@ -42,3 +47,151 @@ func RegisterMonitoringRoutes(router *gin.Engine, controller *Controllers.Monito
 		monitoringGroup.GET("/stats", controller.GetMonitoringStats)
 	}
 }
+
+// RegisterMonitoringDashboardRoutes 注册监控仪表板路由
+// 功能说明：
+// 1. 注册仪表板和组件的增删改查路由
+// 2. 注册组件数据路由，在服务端执行组件保存的查询
+// 3. 注册仪表板导入导出路由
+// 4. 所有路由都需要认证访问，可见性和修改权限由服务按创建者、角色共享判断
+func RegisterMonitoringDashboardRoutes(router *gin.Engine, controller *Controllers.MonitoringDashboardController) {
+	dashboardGroup := router.Group("/api/v1/monitoring/dashboards")
+	dashboardGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	{
+		// 仪表板相关路由
+		dashboardGroup.GET("", controller.GetDashboards)
+		dashboardGroup.POST("", controller.CreateDashboard)
+		dashboardGroup.POST("/import", controller.ImportDashboard)
+		dashboardGroup.GET("/:id", controller.GetDashboard)
+		dashboardGroup.PUT("/:id", controller.UpdateDashboard)
+		dashboardGroup.DELETE("/:id", controller.DeleteDashboard)
+		dashboardGroup.GET("/:id/export", controller.ExportDashboard)
+
+		// 组件相关路由
+		dashboardGroup.POST("/:id/widgets", controller.CreateWidget)
+		dashboardGroup.PUT("/:id/widgets/:widget_id", controller.UpdateWidget)
+		dashboardGroup.DELETE("/:id/widgets/:widget_id", controller.DeleteWidget)
+		dashboardGroup.GET("/:id/widgets/:widget_id/data", controller.GetWidgetData)
+	}
+}
+
+// RegisterMonitoringReportRoutes 注册定时监控报告路由
+// 功能说明：
+// 1. 注册报告定义的增删改查和立即生成路由
+// 2. 注册历史报告列表和下载路由
+// 3. 所有路由都需要管理员权限
+func RegisterMonitoringReportRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.MonitoringReportController) {
+	reportGroup := router.Group("/api/v1/monitoring/reports")
+	reportGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	reportGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+	{
+		// 报告定义相关路由
+		reportGroup.GET("", controller.GetReports)
+		reportGroup.POST("", controller.CreateReport)
+		reportGroup.GET("/:id", controller.GetReport)
+		reportGroup.PUT("/:id", controller.UpdateReport)
+		reportGroup.DELETE("/:id", controller.DeleteReport)
+		reportGroup.POST("/:id/generate", controller.GenerateReport)
+
+		// 历史报告相关路由
+		reportGroup.GET("/:id/files", controller.GetReportFiles)
+		reportGroup.GET("/:id/files/:file_id/download", controller.DownloadReportFile)
+	}
+}
+
+// RegisterMonitoringSLORoutes 注册服务等级目标路由
+// 功能说明：
+// 1. 登录用户可以查看SLO定义和达成情况
+// 2. 创建、更新和删除SLO需要管理员权限
+func RegisterMonitoringSLORoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.MonitoringSLOController) {
+	sloGroup := router.Group("/api/v1/monitoring/slos")
+	sloGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	{
+		sloGroup.GET("", controller.GetSLOs)
+		sloGroup.GET("/status", controller.GetSLOStatuses)
+		sloGroup.GET("/:id", controller.GetSLO)
+		sloGroup.GET("/:id/status", controller.GetSLOStatus)
+
+		sloAdminGroup := sloGroup.Group("")
+		sloAdminGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+		{
+			sloAdminGroup.POST("", controller.CreateSLO)
+			sloAdminGroup.PUT("/:id", controller.UpdateSLO)
+			sloAdminGroup.DELETE("/:id", controller.DeleteSLO)
+		}
+	}
+}
+
+// RegisterMonitoringIncidentRoutes 注册事件路由
+// 功能说明：
+// 1. 登录用户可以查看事件、时间线、MTTA/MTTR统计和复盘，并添加评论
+// 2. 确认和解决事件需要管理员权限
+func RegisterMonitoringIncidentRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.MonitoringIncidentController) {
+	incidentGroup := router.Group("/api/v1/monitoring/incidents")
+	incidentGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	{
+		incidentGroup.GET("", controller.GetIncidents)
+		incidentGroup.GET("/stats", controller.GetIncidentStats)
+		incidentGroup.GET("/:id", controller.GetIncident)
+		incidentGroup.GET("/:id/review", controller.GetIncidentReview)
+		incidentGroup.POST("/:id/comments", controller.CommentIncident)
+
+		incidentAdminGroup := incidentGroup.Group("")
+		incidentAdminGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+		{
+			incidentAdminGroup.POST("/:id/acknowledge", controller.AcknowledgeIncident)
+			incidentAdminGroup.POST("/:id/resolve", controller.ResolveIncident)
+		}
+	}
+}
+
+// RegisterMonitoringSilenceRoutes 注册告警静默和维护窗口路由
+// 功能说明：
+// 1. 登录用户可以查看静默和维护窗口
+// 2. 创建、过期静默和创建、删除维护窗口需要管理员权限
+func RegisterMonitoringSilenceRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.MonitoringSilenceController) {
+	silenceGroup := router.Group("/api/v1/monitoring/silences")
+	silenceGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	{
+		silenceGroup.GET("", controller.GetSilences)
+		silenceGroup.GET("/:id", controller.GetSilence)
+
+		silenceAdminGroup := silenceGroup.Group("")
+		silenceAdminGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+		{
+			silenceAdminGroup.POST("", controller.CreateSilence)
+			silenceAdminGroup.DELETE("/:id", controller.ExpireSilence)
+		}
+	}
+
+	windowGroup := router.Group("/api/v1/monitoring/maintenance-windows")
+	windowGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	{
+		windowGroup.GET("", controller.GetMaintenanceWindows)
+		windowGroup.GET("/:id", controller.GetMaintenanceWindow)
+
+		windowAdminGroup := windowGroup.Group("")
+		windowAdminGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+		{
+			windowAdminGroup.POST("", controller.CreateMaintenanceWindow)
+			windowAdminGroup.DELETE("/:id", controller.DeleteMaintenanceWindow)
+		}
+	}
+}
+
+// RegisterKubernetesRoutes 注册Kubernetes集成路由
+// 功能说明：
+// 1. 外部指标接口注册在 /apis/external.metrics.k8s.io/v1beta1 下，由APIService经API聚合层转发，不经过用户认证
+// 2. 部署信息接口需要管理员权限
+func RegisterKubernetesRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.KubernetesController) {
+	externalMetricsGroup := router.Group("/apis/external.metrics.k8s.io/v1beta1")
+	{
+		externalMetricsGroup.GET("", controller.GetExternalMetricResources)
+		externalMetricsGroup.GET("/namespaces/:namespace/:metric", controller.GetExternalMetric)
+	}
+
+	kubernetesGroup := router.Group("/api/v1/monitoring/kubernetes")
+	kubernetesGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	kubernetesGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+	kubernetesGroup.GET("", controller.GetKubernetesStatus)
+}
@@ -0,0 +1,25 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterNotificationRoutes 注册站内通知路由
+func RegisterNotificationRoutes(router *gin.Engine, controller *Controllers.NotificationController) {
+	// 站内通知路由组，需要认证，只能访问自己的通知
+	notificationGroup := router.Group("/api/v1/notifications")
+	notificationGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	{
+		notificationGroup.GET("", controller.GetNotifications)
+		notificationGroup.GET("/unread-count", controller.GetUnreadCount)
+		notificationGroup.POST("/read-all", controller.MarkAllRead)
+		notificationGroup.POST("/:id/read", controller.MarkRead)
+		notificationGroup.DELETE("/:id", controller.DeleteNotification)
+
+		// 实时推送，默认使用SSE，携带WebSocket升级请求头时使用WebSocket
+		notificationGroup.GET("/stream", Middleware.SkipBodyLogging(), controller.Stream)
+	}
+}
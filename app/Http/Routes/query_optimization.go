@@ -3,6 +3,7 @@ package Routes
 import (
 	"cloud-platform-api/app/Http/Controllers"
 	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Storage"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,14 +13,18 @@ import (
 // 1. 注册慢查询管理相关路由
 // 2. 注册查询统计和分析路由
 // 3. 注册索引建议和优化路由
-// 4. 所有路由都需要认证访问
-func RegisterQueryOptimizationRoutes(router *gin.Engine, controller *Controllers.QueryOptimizationController) {
-	// 查询优化路由组，需要认证
+// 4. 注册慢查询统计和执行计划分析路由
+// 5. 所有路由都需要管理员权限（可应用索引、执行EXPLAIN）
+func RegisterQueryOptimizationRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.QueryOptimizationController) {
+	// 查询优化路由组，需要管理员认证
 	queryOptGroup := router.Group("/api/v1/query-optimization")
 	queryOptGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	queryOptGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
 	{
 		// 慢查询相关路由
 		queryOptGroup.GET("/slow-queries", controller.GetSlowQueries)
+		queryOptGroup.GET("/slow-query-stats", controller.GetSlowQueryStats)
+		queryOptGroup.GET("/slow-query-stats/:id/explain", controller.ExplainSlowQuery)
 
 		// 查询统计相关路由
 		queryOptGroup.GET("/query-statistics", controller.GetQueryStatistics)
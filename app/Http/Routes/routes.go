@@ -1,11 +1,21 @@
 package Routes
 
 import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Grpc"
 	"cloud-platform-api/app/Http/Controllers"
 	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Http/Requests"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/SBOM"
 	"cloud-platform-api/app/Services"
 	"cloud-platform-api/app/Storage"
 	"context"
+	"fmt"
+	"log"
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -27,6 +37,10 @@ import (
 // 12. 注册存储和文件管理路由
 // 13. 注册安全和审计路由
 // 14. 注册性能监控和查询优化路由
+// 15. 注册全文搜索路由
+// 16. 注册日志查询和级别调整路由
+// 17. 注册性能剖析和pprof路由
+// 18. 注册查询优化和慢查询分析路由
 //
 // 中间件配置：
 // - 全局中间件：错误恢复、CORS、超时、速率限制、性能监控、请求日志、SQL日志
@@ -57,14 +71,17 @@ import (
 // 中间件执行顺序（重要）：
 // 1. 错误恢复（Recovery）：最先执行，捕获panic，防止程序崩溃
 // 2. CORS：处理跨域请求，设置响应头
-// 3. 验证中间件：输入验证、SQL注入检测、XSS防护
-// 4. 超时控制：防止长时间运行的请求
-// 5. 速率限制：防止API滥用
-// 6. 性能监控：收集性能指标
-// 7. 请求统计：统计请求信息
-// 8. 请求日志：记录请求和响应
-// 9. SQL日志：记录SQL查询
-// 10. 错误处理：最后执行，处理业务错误
+// 3. 安全响应头：按配置设置CSP、HSTS、X-Frame-Options等，被后续中间件拒绝的响应同样带有安全头
+// 4. 语言解析：根据查询参数、Accept-Language确定响应语言
+// 5. 验证中间件：输入验证、SQL注入检测、XSS防护
+// 6. 超时控制：请求上下文带截止时间，超时后取消数据库查询和出站请求，按路由分组配置
+// 7. 速率限制：防止API滥用
+// 8. 弹性保护：依赖熔断时快速返回503，按路由限制并发
+// 9. 性能监控：收集性能指标
+// 10. 请求统计：统计请求信息
+// 11. 请求日志：记录请求和响应
+// 12. SQL日志：记录SQL查询
+// 13. 错误处理：最后执行，处理业务错误
 //
 // 中间件顺序的重要性：
 // - 错误恢复必须最先执行，才能捕获后续中间件的panic
@@ -86,19 +103,35 @@ import (
 // - 中间件顺序很重要，不要随意调整
 // - 某些中间件可能影响响应时间，需要权衡
 // - 日志中间件可能产生大量日志，需要合理配置
-func RegisterRoutes(engine *gin.Engine, storageManager *Storage.StorageManager, logManager *Services.LogManagerService) {
+// - 路由注册只创建服务实例，后台任务登记在返回的 BackgroundServices 中，由应用启动时统一启动
+func RegisterRoutes(engine *gin.Engine, storageManager *Storage.StorageManager, logManager *Services.LogManagerService) *BackgroundServices {
+	background := &BackgroundServices{}
+
 	// 创建中间件实例
 	// 每个中间件负责不同的功能（日志、错误处理、安全等）
 	requestLogMiddleware := Middleware.NewRequestLogMiddleware(logManager)
 	sqlLogMiddleware := Middleware.NewSQLLogMiddleware(logManager)
 	errorHandlingMiddleware := Middleware.NewErrorHandlingMiddleware(storageManager, nil)
 	recoveryMiddleware := Middleware.NewRecoveryMiddleware(storageManager)
+	if logManager != nil {
+		recoveryMiddleware.SetErrorReporter(logManager.ErrorReporter())
+	}
 	corsMiddleware := Middleware.NewCORSMiddleware()
+	localeMiddleware := Middleware.NewLocaleMiddleware(nil)
 	timeoutMiddleware := Middleware.NewTimeoutMiddleware(storageManager)
 	performanceMiddleware := Middleware.NewPerformanceMiddleware(storageManager)
 	rateLimitMiddleware := Middleware.NewRateLimitMiddleware(storageManager)
 	versionMiddleware := Middleware.NewVersionMiddleware(storageManager)
 
+	// 创建安全响应头中间件
+	// CSP、HSTS、X-Frame-Options、Referrer-Policy、Permissions-Policy 由安全配置决定
+	var securityHeadersMiddleware *Middleware.SecurityHeadersMiddleware
+	if globalConfig := Config.GetConfig(); globalConfig != nil {
+		securityHeadersMiddleware = Middleware.NewSecurityHeadersMiddleware(&globalConfig.Security)
+	} else {
+		securityHeadersMiddleware = Middleware.NewSecurityHeadersMiddleware(nil)
+	}
+
 	// 创建增强的验证中间件
 	// 包含输入验证、SQL注入检测、XSS防护等功能
 	validationMiddleware := Middleware.NewEnhancedValidationMiddleware(storageManager, nil)
@@ -106,32 +139,263 @@ func RegisterRoutes(engine *gin.Engine, storageManager *Storage.StorageManager,
 	// 创建请求统计中间件
 	// 用于收集和分析请求统计信息
 	monitoringService := Services.NewOptimizedMonitoringService()
+	// 最近采样缓冲区需在首轮采集前设置容量，健康检查和仪表板优先从内存读取指标
+	if globalConfig := Config.GetConfig(); globalConfig != nil {
+		monitoringService.MonitoringCore().SetRecentBufferSize(globalConfig.Monitoring.Realtime.BufferSize)
+		monitoringService.SetStaleAfter(globalConfig.Monitoring.Realtime.StaleAfter)
+
+		// 指标基数保护：自定义指标和外部推送的指标共享同一份序列统计，告警经监控核心的通知管道发送
+		cardinalityGuard := Services.NewMetricCardinalityGuard(&globalConfig.Monitoring)
+		cardinalityGuard.OnAlert(func(alert Services.MonitoringAlert) {
+			monitoringService.MonitoringCore().Pipeline().Publish(alert)
+			logManager.LogBusiness(context.Background(), "monitoring", alert.Type, alert.Message, alert.Metadata)
+		})
+		monitoringService.SetCardinalityGuard(cardinalityGuard)
+	}
 	// 启动监控服务（用于 /metrics 暴露 Prometheus 指标 & 后台定时收集）
 	// 注意：启动失败不应影响主服务启动，但需要记录日志便于排查
-	if err := monitoringService.Start(); err != nil {
-		logManager.LogBusiness(context.Background(), "monitoring", "start_failed", "监控服务启动失败", map[string]interface{}{
-			"error": err.Error(),
+	background.add("monitoring", func() {
+		if err := monitoringService.Start(); err != nil {
+			logManager.LogBusiness(context.Background(), "monitoring", "start_failed", "监控服务启动失败", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	})
+	requestStatsMiddleware := Middleware.NewRequestStatsMiddleware(storageManager, monitoringService)
+
+	// 创建弹性保护中间件
+	// 定期检查数据库和Redis，必需依赖熔断时快速拒绝请求；状态变化写入监控指标
+	resilienceMiddleware := Middleware.NewResilienceMiddleware(nil)
+	resilienceMiddleware.SetStorageManager(storageManager)
+	resilienceMiddleware.RegisterDependency("database", func(ctx context.Context) error {
+		db := Database.GetDB()
+		if db == nil {
+			return fmt.Errorf("数据库未初始化")
+		}
+		// 健康检查不经过GORM回调，单独执行注入的数据库故障
+		if err := Services.DefaultFaultInjector().InjectService(ctx, Services.FaultServiceDatabase); err != nil {
+			return err
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})
+	if redisConfig := Config.GetRedisConfig(); redisConfig != nil && redisConfig.IsConfigured() {
+		redisClient := Services.NewRedisUniversalClient(redisConfig)
+		resilienceMiddleware.RegisterDependency("redis", func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
 		})
 	}
-	requestStatsMiddleware := Middleware.NewRequestStatsMiddleware(storageManager, monitoringService)
+	resilienceMiddleware.OnAlert(func(alert Services.MonitoringAlert) {
+		monitoringService.AddMetric("resilience_dependency_state_change", 1, map[string]string{
+			"dependency": fmt.Sprint(alert.Metadata["dependency"]),
+			"state":      fmt.Sprint(alert.Metadata["to"]),
+		})
+		logManager.LogBusiness(context.Background(), "resilience", alert.Type, alert.Message, alert.Metadata)
+	})
+	background.add("resilience", func() {
+		if err := resilienceMiddleware.Start(); err != nil {
+			logManager.LogBusiness(context.Background(), "resilience", "start_failed", "依赖健康检查启动失败", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	})
+
+	// 创建热点模型缓存
+	// 认证、角色判断和告警规则读取的用户和规则先读进程内缓存，通过GORM写入后对应表的缓存失效
+	var modelCache *Services.ModelCache
+	if modelCacheConfig := Config.GetModelCacheConfig(); modelCacheConfig != nil && modelCacheConfig.Enabled {
+		if db := Database.GetDB(); db != nil {
+			modelCache = Services.NewModelCache(modelCacheConfig)
+			// 失效回调在应用启动时注册，注册成功后才设置全局缓存
+			background.add("model_cache", func() {
+				if err := modelCache.InstallGormCallbacks(db); err != nil {
+					// 无法保证写入后失效时不启用缓存
+					logManager.LogBusiness(context.Background(), "model_cache", "callback_failed", "模型缓存失效回调注册失败，不启用模型缓存", map[string]interface{}{
+						"error": err.Error(),
+					})
+					return
+				}
+				Services.SetDefaultModelCache(modelCache)
+			})
+		}
+	}
+
+	// 创建故障注入器（默认不启用，仅用于预发环境验证熔断和告警）
+	// 数据库故障通过GORM回调执行，Redis故障通过客户端钩子执行，路由故障由中间件执行
+	var faultInjector *Services.FaultInjector
+	if faultConfig := Config.GetFaultInjectionConfig(); faultConfig != nil && faultConfig.Enabled {
+		faultInjector = Services.NewFaultInjector(faultConfig)
+		Services.SetDefaultFaultInjector(faultInjector)
+		if db := Database.GetDB(); db != nil {
+			background.add("fault_injection", func() {
+				if err := faultInjector.InstallGormCallbacks(db); err != nil {
+					logManager.LogBusiness(context.Background(), "fault_injection", "callback_failed", "数据库故障注入回调注册失败", map[string]interface{}{
+						"error": err.Error(),
+					})
+				}
+			})
+		}
+		logManager.LogBusiness(context.Background(), "fault_injection", "enabled", "故障注入已启用，不要在生产环境启用", nil)
+	}
+
+	// 创建请求调试记录存储（默认不启用）
+	// 管理员请求携带调试请求头时，记录请求执行的SQL、缓存操作和出站HTTP调用
+	var debugStore *Services.DebugRequestStore
+	debugConfig := Config.GetDebugModeConfig()
+	if debugConfig != nil && debugConfig.Enabled {
+		debugStore = Services.NewDebugRequestStore(debugConfig)
+	}
+
+	// 创建请求检查器（默认不启用）
+	// 按环境、用户和采样率记录最近请求的请求、响应、异常、SQL和通知，异步写入数据库
+	var requestInspector *Services.RequestInspector
+	if inspectorConfig := Config.GetInspectorConfig(); inspectorConfig != nil && inspectorConfig.Enabled {
+		if db := Database.GetDB(); db != nil {
+			globalConfig := Config.GetConfig()
+			inspector, err := Services.NewRequestInspector(db, inspectorConfig, globalConfig.Log.Masking, globalConfig.Server.Environment)
+			if err != nil {
+				logManager.LogBusiness(context.Background(), "inspector", "start_failed", "请求检查器创建失败", map[string]interface{}{
+					"error": err.Error(),
+				})
+			} else {
+				requestInspector = inspector
+				background.add("inspector", func() {
+					requestInspector.Start()
+				})
+			}
+		}
+	}
+
+	// 创建用量计量服务
+	// 需在创建指标推送、通知分发等服务之前设置全局服务；认证中间件和API密钥中间件确认用户后统计API调用并检查限额
+	var meteringService *Services.MeteringService
+	if meteringConfig := Config.GetMeteringConfig(); meteringConfig != nil && meteringConfig.Enabled {
+		if db := Database.GetDB(); db != nil {
+			meteringService = Services.NewMeteringService(db, meteringConfig)
+			background.add("metering", func() {
+				meteringService.Start(context.Background())
+			})
+			Services.SetDefaultMeteringService(meteringService)
+		}
+	}
+
+	// 请求超时按路由分组配置，事件流和WebSocket等长连接默认不设置超时
+	requestTimeout := timeoutMiddleware.HandleGroups(Config.GetRequestTimeoutConfig())
+
+	// 请求ID中间件在所有中间件之前执行，panic上报、日志、审计记录和出站请求都能获取请求ID和关联ID
+	engine.Use(Middleware.NewRequestIDMiddleware().Handle())
+
+	// 降级模式启动时，不可用依赖相关的路由直接返回503
+	if startup := Services.DefaultStartupOrchestrator(); startup != nil {
+		engine.Use(Middleware.NewStartupGateMiddleware(startup).Handle())
+	}
+
+	// 接口文档注册表
+	// 通过注册表分组注册的路由同时声明请求和响应类型，/openapi.json 由这些声明生成，按配置校验请求
+	openAPIConfig := Config.GetOpenAPIConfig()
+	apiRegistry := OpenAPI.NewRegistry(OpenAPI.Info{
+		Title:       "Cloud Platform API",
+		Description: "云平台API，响应统一为 {success, message, data}，列表接口包含分页信息 meta",
+		Version:     "1.0.0",
+	})
+	langParam := "lang"
+	if i18nConfig := Config.GetI18nConfig(); i18nConfig != nil && i18nConfig.QueryParam != "" {
+		langParam = i18nConfig.QueryParam
+	}
+	apiRegistry.AllowQueryParams(langParam)
+	OpenAPI.SetDefaultRegistry(apiRegistry)
+	var openAPIValidation gin.HandlerFunc
+	if openAPIConfig != nil {
+		if openAPIConfig.ServerURL != "" {
+			apiRegistry.SetServers(OpenAPI.Server{URL: openAPIConfig.ServerURL})
+		}
+		if openAPIConfig.Validation == Config.OpenAPIValidationLenient || openAPIConfig.Validation == Config.OpenAPIValidationStrict {
+			strict := openAPIConfig.Validation == Config.OpenAPIValidationStrict
+			openAPIValidation = Middleware.NewOpenAPIValidationMiddleware(apiRegistry, strict).Handle()
+		}
+	}
+	var compression gin.HandlerFunc
+	if compressionConfig := Config.GetCompressionConfig(); compressionConfig != nil && compressionConfig.Enabled {
+		compressionMiddleware := Middleware.NewCompressionMiddleware(compressionConfig)
+		compressionMiddleware.SetStorageManager(storageManager)
+		compression = compressionMiddleware.Handle()
+	}
+	var gateway gin.HandlerFunc
+	if gatewayConfig := Config.GetGatewayConfig(); gatewayConfig != nil && gatewayConfig.Enabled {
+		gatewayMiddleware := Middleware.NewGatewayMiddleware(gatewayConfig)
+		gatewayMiddleware.SetStorageManager(storageManager)
+		gateway = gatewayMiddleware.Handle()
+	}
+	var debugMode gin.HandlerFunc
+	if debugStore != nil {
+		debugMode = Middleware.NewDebugModeMiddleware(debugStore, debugConfig).Handle()
+	}
+	var inspector gin.HandlerFunc
+	if requestInspector != nil {
+		inspector = Middleware.NewInspectorMiddleware(requestInspector, Config.GetInspectorConfig().MaxOperations).Handle()
+	}
+	var faultInjection gin.HandlerFunc
+	if faultInjector != nil {
+		faultInjection = Middleware.NewFaultInjectionMiddleware(faultInjector).Handle()
+	}
 
 	// 添加全局中间件
-	// 注意：中间件的执行顺序很重要，影响功能和性能
-	// 执行顺序：从上到下，从左到右
-	engine.Use(
-		recoveryMiddleware.Handle(),                    // 1. 错误恢复中间件（最先执行，捕获panic）
-		corsMiddleware.Handle(),                        // 2. CORS中间件（处理跨域请求）
-		validationMiddleware.Handle(),                  // 3. 增强的验证中间件（输入验证、安全检测）
-		validationMiddleware.ValidateJSON(),            // 4. JSON验证中间件（验证JSON格式）
-		validationMiddleware.ValidateFileUpload(),      // 5. 文件上传验证中间件（验证文件类型和大小）
-		timeoutMiddleware.Handle(30*time.Second),       // 6. 请求超时中间件（30秒超时）
-		rateLimitMiddleware.Handle(100, 1*time.Minute), // 7. 全局速率限制（每分钟100次请求）
-		performanceMiddleware.Handle(),                 // 8. 性能监控中间件（收集性能指标）
-		requestStatsMiddleware.Handle(),                // 9. 请求统计中间件（统计请求信息）
-		requestLogMiddleware.RequestLog(),              // 10. 自定义请求日志（记录请求和响应）
-		sqlLogMiddleware.Handle(),                      // 11. SQL日志中间件（记录SQL查询）
-		errorHandlingMiddleware.Handle(),               // 12. 错误处理中间件（最后执行，处理业务错误）
-	)
+	// 执行顺序、禁用、按环境挂载和生效范围由中间件管道配置（MIDDLEWARE_*）声明，默认顺序：
+	// 错误恢复 → CORS → 安全响应头 → 请求调试 → 响应压缩 → 请求检查器 → 语言解析 → 网关策略 → 输入验证 → JSON验证 → 文件上传验证 → 请求超时 → 全局速率限制（每分钟100次）
+	// → 弹性保护 → 性能监控 → 请求统计 → 请求日志 → SQL日志 → 错误处理 → 故障注入 → 接口文档校验
+	// 故障注入在监控和统计之后执行，注入的延迟和错误计入请求指标
+	environment := ""
+	if serverConfig := Config.GetServerConfig(); serverConfig != nil {
+		environment = serverConfig.Environment
+	}
+	// 启动时已验证配置，这里无效说明配置未加载，按默认顺序组装
+	pipelineConfig := Config.GetMiddlewarePipelineConfig()
+	if pipelineConfig != nil {
+		if err := pipelineConfig.Validate(); err != nil {
+			log.Printf("中间件管道配置无效，使用默认顺序: %v", err)
+			pipelineConfig = nil
+		}
+	}
+	pipeline := Middleware.NewMiddlewarePipeline(pipelineConfig, environment).
+		Register(Config.MiddlewareRecovery, recoveryMiddleware.Handle()).
+		Register(Config.MiddlewareCORS, corsMiddleware.Handle()).
+		Register(Config.MiddlewareSecurityHeaders, securityHeadersMiddleware.Handle()).
+		Register(Config.MiddlewareDebugMode, debugMode).
+		Register(Config.MiddlewareCompression, compression).
+		Register(Config.MiddlewareInspector, inspector).
+		Register(Config.MiddlewareLocale, localeMiddleware.Handle()).
+		Register(Config.MiddlewareGateway, gateway).
+		Register(Config.MiddlewareValidation, validationMiddleware.Handle()).
+		Register(Config.MiddlewareValidateJSON, validationMiddleware.ValidateJSON()).
+		Register(Config.MiddlewareValidateFileUpload, validationMiddleware.ValidateFileUpload()).
+		Register(Config.MiddlewareTimeout, requestTimeout).
+		Register(Config.MiddlewareRateLimit, rateLimitMiddleware.Handle(100, 1*time.Minute)).
+		Register(Config.MiddlewareResilience, resilienceMiddleware.Handle()).
+		Register(Config.MiddlewarePerformance, performanceMiddleware.Handle()).
+		Register(Config.MiddlewareRequestStats, requestStatsMiddleware.Handle()).
+		Register(Config.MiddlewareRequestLog, requestLogMiddleware.RequestLog()).
+		Register(Config.MiddlewareSQLLog, sqlLogMiddleware.Handle()).
+		Register(Config.MiddlewareErrorHandling, errorHandlingMiddleware.Handle()).
+		Register(Config.MiddlewareFaultInjection, faultInjection).
+		Register(Config.MiddlewareOpenAPIValidation, openAPIValidation)
+	globalMiddleware, middlewareNames, err := pipeline.Build()
+	if err != nil {
+		log.Fatalf("组装全局中间件失败: %v", err)
+	}
+	engine.Use(globalMiddleware...)
+	if logManager != nil {
+		logManager.LogBusiness(context.Background(), "middleware", "pipeline_built", "全局中间件已挂载", map[string]interface{}{
+			"environment": environment,
+			"middleware":  middlewareNames,
+		})
+	}
+
+	if openAPIConfig == nil || openAPIConfig.Enabled {
+		engine.GET("/openapi.json", apiRegistry.Handler(engine))
+	}
 
 	// API版本分组
 	// 创建v1版本的API路由组
@@ -160,6 +424,10 @@ func RegisterRoutes(engine *gin.Engine, storageManager *Storage.StorageManager,
 	engine.GET("/health/ready", healthController.Readiness)
 	engine.HEAD("/health/ready", healthController.Readiness)
 
+	// 启动依赖就绪检查：各依赖的等待状态、重试次数和降级模式下暂停的功能
+	engine.GET("/readyz", healthController.DependencyReadiness)
+	engine.HEAD("/readyz", healthController.DependencyReadiness)
+
 	// 存活检查：检查服务是否存活
 	// 用于Kubernetes的liveness probe
 	engine.GET("/health/live", healthController.Liveness)
@@ -194,12 +462,36 @@ func RegisterRoutes(engine *gin.Engine, storageManager *Storage.StorageManager,
 	// 认证相关路由
 	authController := Controllers.NewAuthController()
 	authGroup := v1.Group("/auth")
+	authAPI := apiRegistry.Group(authGroup, "认证")
 	{
-		authGroup.POST("/register", authController.Register)
-		authGroup.POST("/login", authController.Login)
-		authGroup.POST("/logout", authController.Logout)
-		authGroup.POST("/refresh", authController.RefreshToken)
+		authAPI.POST("/register", OpenAPI.Route{
+			Summary:  "用户注册",
+			Request:  Requests.RegisterRequest{},
+			Response: Models.User{},
+			Status:   http.StatusCreated,
+		}, authController.Register)
+		authAPI.POST("/login", OpenAPI.Route{
+			Summary:  "用户登录",
+			Request:  Requests.LoginRequest{},
+			Response: Controllers.LoginResponse{},
+			Errors:   []int{http.StatusUnauthorized},
+		}, authController.Login)
+		authAPI.POST("/logout", OpenAPI.Route{
+			Summary: "用户登出，当前令牌加入黑名单",
+			Errors:  []int{http.StatusUnauthorized},
+		}, authController.Logout)
+		authAPI.POST("/refresh", OpenAPI.Route{
+			Summary:     "刷新令牌",
+			Description: "请求头 Authorization 携带即将过期的令牌",
+			Response:    Controllers.TokenResponse{},
+			Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized},
+		}, authController.RefreshToken)
 	}
+	apiRegistry.Group(authGroup, "认证", OpenAPI.BearerAuth).POST("/change-password", OpenAPI.Route{
+		Summary:     "修改密码",
+		Description: "必须修改密码的用户也可以访问",
+		Request:     Requests.PasswordChangeRequest{},
+	}, Middleware.NewAuthMiddleware().Handle(), authController.ChangePassword)
 
 	// 用户管理路由
 	userController := Controllers.NewUserController()
@@ -207,6 +499,8 @@ func RegisterRoutes(engine *gin.Engine, storageManager *Storage.StorageManager,
 	userGroup.Use(Middleware.NewAuthMiddleware().Handle())
 	{
 		userGroup.GET("/", userController.GetUsers)
+		userGroup.GET("/me/logins", userController.GetMyLogins)
+		userGroup.GET("/me/activity", userController.GetMyActivity)
 		userGroup.GET("/:id", userController.GetUser)
 		userGroup.PUT("/:id", userController.UpdateUser)
 		userGroup.DELETE("/:id", userController.DeleteUser)
@@ -256,13 +550,59 @@ func RegisterRoutes(engine *gin.Engine, storageManager *Storage.StorageManager,
 	// 监控路由
 	monitoringController := Controllers.NewMonitoringController()
 	monitoringController.SetMonitoringService(monitoringService)
+	// 指标和告警按当前角色的可见级别裁剪（MONITORING_VISIBILITY_*），需要认证；健康状态供探测使用，不需要认证
+	if globalConfig := Config.GetConfig(); globalConfig != nil {
+		Services.SetDefaultMonitoringVisibility(Services.NewMonitoringVisibility(&globalConfig.Monitoring.Visibility))
+	}
 	monitoringGroup := v1.Group("/monitoring")
 	{
-		monitoringGroup.GET("/metrics", monitoringController.GetMetrics)
+		monitoringGroup.GET("/metrics", Middleware.NewAuthMiddleware().Handle(), monitoringController.GetMetrics)
 		monitoringGroup.GET("/health", monitoringController.GetSystemHealth)
-		monitoringGroup.GET("/alerts", monitoringController.GetAlerts)
+		monitoringGroup.GET("/alerts", Middleware.NewAuthMiddleware().Handle(), monitoringController.GetAlerts)
+
+		// 实时推送指标和告警状态变化（SSE），需要认证
+		monitoringGroup.GET("/stream", Middleware.NewAuthMiddleware().Handle(), Middleware.SkipBodyLogging(), monitoringController.StreamEvents)
+	}
+
+	// 缓存监控路由（仅管理员）
+	// 配置了Redis时定期采集INFO指标并按 MONITORING_CACHE_* 阈值告警，支持集群和哨兵模式
+	if redisConfig := Config.GetRedisConfig(); redisConfig != nil && redisConfig.IsConfigured() {
+		cacheMonitoringService := Services.NewCacheMonitoringService(nil, redisConfig)
+		background.add("cache_monitoring", func() {
+			if err := cacheMonitoringService.Start(); err != nil {
+				logManager.LogBusiness(context.Background(), "monitoring", "cache_start_failed", "缓存监控服务启动失败", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+		})
+		monitoringController.SetCacheMonitoringService(cacheMonitoringService)
+		cacheMonitoringGroup := v1.Group("/monitoring/cache")
+		cacheMonitoringGroup.Use(Middleware.NewAuthMiddleware().Handle())
+		cacheMonitoringGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+		cacheMonitoringGroup.GET("", monitoringController.GetCacheMetrics)
 	}
 
+	// 外部依赖调用统计路由（仅管理员）
+	// 威胁情报、Webhook等出站请求共享全局客户端，按依赖统计延迟、错误率和熔断状态
+	monitoringController.SetOutboundHTTPClient(Services.GetOutboundHTTPClient())
+	dependencyGroup := v1.Group("/monitoring/dependencies")
+	dependencyGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	dependencyGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+	dependencyGroup.GET("", monitoringController.GetDependencies)
+
+	// 入站弹性保护状态路由（仅管理员）
+	monitoringController.SetResilienceMiddleware(resilienceMiddleware)
+	resilienceGroup := v1.Group("/monitoring/resilience")
+	resilienceGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	resilienceGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+	resilienceGroup.GET("", monitoringController.GetResilience)
+
+	// 指标基数统计路由（仅管理员），按序列占用比例列出基数最高的指标
+	cardinalityGroup := v1.Group("/monitoring/cardinality")
+	cardinalityGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	cardinalityGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+	cardinalityGroup.GET("", monitoringController.GetCardinality)
+
 	// Prometheus 默认抓取路径通常是 /metrics，这里提供一个顶层别名，避免 404 造成噪音
 	engine.GET("/metrics", monitoringController.GetMetrics)
 	engine.HEAD("/metrics", monitoringController.GetMetrics)
@@ -274,6 +614,699 @@ func RegisterRoutes(engine *gin.Engine, storageManager *Storage.StorageManager,
 		wsGroup.GET("/", wsController.Connect)
 	}
 
+	// 全文搜索路由
+	// 搜索需要认证，索引管理（状态、重建、删除）仅管理员可用
+	searchService := Services.NewSearchService(nil)
+	// 搜索引擎驱动启动失败不影响主服务，搜索请求会返回错误
+	background.add("search", func() {
+		if err := searchService.Start(); err != nil {
+			logManager.LogBusiness(context.Background(), "search", "start_failed", "搜索服务启动失败", map[string]interface{}{
+				"driver": searchService.Driver().Name(),
+				"error":  err.Error(),
+			})
+		}
+	})
+	searchController := Controllers.NewSearchController()
+	searchController.SetSearchService(searchService)
+	searchGroup := v1.Group("/search")
+	searchGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	{
+		searchGroup.GET("", searchController.Search)
+
+		searchIndexGroup := searchGroup.Group("/indices")
+		searchIndexGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+		{
+			searchIndexGroup.GET("", searchController.GetIndices)
+			searchIndexGroup.POST("/:index/reindex", searchController.Reindex)
+			searchIndexGroup.DELETE("/:index", searchController.DropIndex)
+		}
+	}
+
+	// 日志查询、运行时级别调整和文件维护路由（仅管理员）
+	// 实时跟踪默认使用SSE，携带WebSocket升级请求头时使用WebSocket
+	// 启用 LOG_ARCHIVE_ENABLED 时压缩后的日志文件上传到 ARCHIVE_STORE 配置的归档存储
+	if logConfig := logManager.GetConfig(); logConfig != nil && logConfig.Rotation.Archive {
+		if archiveConfig := Config.GetArchiveConfig(); archiveConfig != nil {
+			if archiveStore, err := Services.NewArchiveObjectStore(archiveConfig); err == nil {
+				logManager.SetArchiveStore(archiveStore)
+			} else {
+				log.Printf("日志归档存储初始化失败: %v", err)
+			}
+		}
+	}
+	logQueryController := Controllers.NewLogQueryController(logManager)
+	logQueryGroup := v1.Group("/logs")
+	logQueryGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	logQueryGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+	{
+		logQueryGroup.GET("/search", logQueryController.Search)
+		logQueryGroup.GET("/files", logQueryController.GetFiles)
+		logQueryGroup.GET("/tail/:logger", Middleware.SkipBodyLogging(), logQueryController.Tail)
+		logQueryGroup.GET("/stats", logQueryController.GetStats)
+		logQueryGroup.GET("/levels", logQueryController.GetLevels)
+		logQueryGroup.PUT("/levels/:logger", logQueryController.UpdateLevel)
+		logQueryGroup.POST("/rotate", logQueryController.Rotate)
+		logQueryGroup.POST("/flush", logQueryController.Flush)
+	}
+
+	// 性能剖析路由（仅管理员）
+	// 协程数或堆内存突破应用监控阈值时自动采集剖析文件，pprof端点可通过配置关闭
+	profilingService := Services.NewProfilingService(nil)
+	background.add("profiling", func() {
+		if err := profilingService.Start(); err != nil {
+			logManager.LogBusiness(context.Background(), "profiling", "start_failed", "性能剖析服务启动失败", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	})
+	profilingController := Controllers.NewProfilingController(profilingService, logManager)
+	profileGroup := v1.Group("/profiles")
+	profileGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	profileGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+	{
+		profileGroup.GET("", profilingController.ListProfiles)
+		profileGroup.POST("", profilingController.CaptureProfile)
+		profileGroup.GET("/:name", profilingController.DownloadProfile)
+		profileGroup.DELETE("/:name", profilingController.DeleteProfile)
+	}
+	if profilingService.GetConfig().Profiling.Enabled {
+		pprofGroup := engine.Group("/debug/pprof")
+		pprofGroup.Use(Middleware.NewAuthMiddleware().Handle())
+		pprofGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+		pprofGroup.GET("/*name", profilingController.Pprof)
+	}
+
+	// 查询优化和慢查询分析路由（仅管理员）
+	// 慢查询分析以GORM插件方式记录每条SQL的耗时，插件在应用启动时注册，数据库未初始化时不注册
+	if db := Database.GetDB(); db != nil {
+		queryOptService := Services.NewQueryOptimizationService(db, nil)
+		background.add("query_optimization", queryOptService.Start)
+		queryOptController := Controllers.NewQueryOptimizationController()
+		queryOptController.SetQueryOptimizationService(queryOptService)
+		RegisterQueryOptimizationRoutes(engine, storageManager, queryOptController)
+	}
+
+	// 过期数据清理调度器和管理路由（仅管理员）
+	// 各服务的过期数据清理注册为调度器中的任务，按批删除并上报进度，路由注册完成后启动
+	cleanupScheduler := Services.NewCleanupScheduler(Config.GetCleanupConfig())
+	Services.SetDefaultCleanupScheduler(cleanupScheduler)
+	RegisterCleanupRoutes(engine, storageManager, Controllers.NewCleanupController(cleanupScheduler))
+
+	// 领域事件总线和管理路由（仅管理员）
+	// 用户注册、告警触发/恢复、备份完成等事件随业务事务写入 outbox 表，需在发布事件的服务之前初始化
+	if db := Database.GetDB(); db != nil {
+		if eventBusConfig := Config.GetEventBusConfig(); eventBusConfig != nil && eventBusConfig.Enabled {
+			eventBus := Services.NewEventBusFromConfig(db, *eventBusConfig)
+			Services.SetDefaultEventBus(eventBus)
+			background.add("event_bus", func() {
+				eventBus.Start()
+			})
+			cleanupScheduler.Register("outbox_events", "清理已发布和失败的过期事件", 0, eventBus.Purge)
+			RegisterEventBusRoutes(engine, storageManager, Controllers.NewEventBusController(eventBus))
+		}
+	}
+
+	// Webhook订阅管理路由（仅管理员）
+	// 订阅作为事件总线的处理器接收领域事件，事件总线未启用时只能发送测试事件
+	if db := Database.GetDB(); db != nil {
+		if webhookConfig := Config.GetWebhookConfig(); webhookConfig != nil && webhookConfig.Enabled {
+			webhookService := Services.NewWebhookService(db, webhookConfig)
+			background.add("webhooks", func() {
+				webhookService.Start()
+			})
+			cleanupScheduler.Register("webhook_deliveries", "清理过期的Webhook投递记录", 0, webhookService.Purge)
+			if eventBus := Services.DefaultEventBus(); eventBus != nil {
+				eventBus.Subscribe(Services.EventAllTypes, "webhooks", webhookService.HandleEvent)
+			} else {
+				logManager.LogBusiness(context.Background(), "webhook", "event_bus_disabled", "事件总线未启用，Webhook订阅不会收到平台事件", nil)
+			}
+			RegisterWebhookRoutes(engine, storageManager, Controllers.NewWebhookController(webhookService))
+		}
+	}
+
+	// 故障注入管理路由（仅管理员）
+	if faultInjector != nil {
+		RegisterFaultInjectionRoutes(engine, storageManager, Controllers.NewFaultInjectionController(faultInjector))
+	}
+	if debugStore != nil {
+		RegisterDebugRoutes(engine, storageManager, Controllers.NewDebugController(debugStore))
+	}
+	if requestInspector != nil {
+		cleanupScheduler.Register("inspector_entries", "清理过期和超出数量上限的请求检查器记录", 0, requestInspector.Purge)
+		RegisterInspectorRoutes(engine, storageManager, Controllers.NewInspectorController(requestInspector))
+	}
+
+	// 回收站路由（仅管理员），超过保留时间的记录定期彻底删除
+	// 删除用户、API密钥和仪表板时按删除编排服务的策略处理关联数据，删除用户后撤销其已签发的token
+	if db := Database.GetDB(); db != nil {
+		deletionService := Services.NewDeletionService(db, Config.GetTrashConfig())
+		if globalConfig := Config.GetConfig(); globalConfig != nil {
+			deletionService.SetTokenBlacklistService(newRedisTokenBlacklistService(globalConfig.Redis))
+		}
+		Services.SetDefaultDeletionService(deletionService)
+
+		trashService := Services.NewTrashService(db, Config.GetTrashConfig())
+		background.add("trash", func() {
+			trashService.StartPurger(context.Background())
+		})
+		RegisterTrashRoutes(engine, storageManager, Controllers.NewTrashController(trashService))
+	}
+
+	// 管理员模拟登录路由（仅管理员）
+	// 模拟令牌经认证中间件校验模拟会话，模拟期间的每个请求记录审计日志，被模拟的用户收到站内通知
+	if db := Database.GetDB(); db != nil {
+		impersonationService := Services.NewImpersonationService(db, Config.GetImpersonationConfig())
+		Services.SetDefaultImpersonationService(impersonationService)
+		RegisterImpersonationRoutes(engine, storageManager, Controllers.NewImpersonationController(impersonationService))
+	}
+
+	// 敏感操作审批路由（仅管理员）
+	// 删除告警规则、恢复备份、解锁管理员账户和修改访问控制由审批中间件拦截，需要另一名管理员审批后携带审批ID执行
+	var approvalService *Services.ApprovalService
+	if db := Database.GetDB(); db != nil {
+		approvalService = Services.NewApprovalService(db, Config.GetApprovalConfig())
+		Services.SetDefaultApprovalService(approvalService)
+		RegisterApprovalRoutes(engine, storageManager, Controllers.NewApprovalController(approvalService))
+	}
+
+	// 请求签名客户端管理路由（仅管理员）
+	// 服务间调用可以使用 HMAC-SHA256 请求签名代替JWT，认证中间件通过全局请求签名服务验证签名
+	if db := Database.GetDB(); db != nil {
+		if signingConfig := Config.GetRequestSigningConfig(); signingConfig != nil && signingConfig.Enabled {
+			signingService := Services.NewRequestSigningService(db, signingConfig)
+			Services.SetDefaultRequestSigningService(signingService)
+			cleanupScheduler.Register("signing_nonces", "清理过期的请求签名nonce", signingService.NonceCleanupInterval(), signingService.PurgeNonces)
+			RegisterSigningClientRoutes(engine, storageManager, Controllers.NewSigningClientController(signingService))
+		}
+	}
+
+	// 站内通知路由
+	// 备份、安全告警、密码过期等系统事件通过全局通知服务生成通知，需在安全防护之前初始化
+	if db := Database.GetDB(); db != nil {
+		notificationService := Services.NewNotificationService(db, nil)
+		Services.SetDefaultNotificationService(notificationService)
+		cleanupScheduler.Register("notifications", "清理超过保留时间的站内通知", 24*time.Hour, notificationService.Cleanup)
+		RegisterNotificationRoutes(engine, Controllers.NewNotificationController(notificationService))
+	}
+
+	// 用户偏好设置路由
+	// 密码过期提醒、新设备登录提醒等用户通知按偏好设置中的通知渠道开关发送
+	if db := Database.GetDB(); db != nil {
+		settingsService := Services.NewUserSettingsService(db, Config.GetUserSettingsConfig(), nil)
+		Services.SetDefaultUserSettingsService(settingsService)
+		RegisterUserSettingsRoutes(engine, Controllers.NewUserSettingsController(settingsService))
+	}
+
+	// 邮件发送队列和投递管理路由
+	// 投递记录保存在数据库中，需在安全防护之前注册，使锁定通知、密码过期提醒等邮件走发送队列
+	if db := Database.GetDB(); db != nil {
+		if globalConfig := Config.GetConfig(); globalConfig != nil && globalConfig.Email.IsConfigured() {
+			mailService := Services.NewMailService(db, &globalConfig.Email)
+			background.add("mail", func() {
+				mailService.Start()
+			})
+			Services.SetDefaultMailService(mailService)
+			RegisterMailRoutes(engine, storageManager, Controllers.NewMailController(mailService, globalConfig.Email.WebhookSecret))
+		}
+	}
+
+	// 用量和限额路由
+	if meteringService != nil {
+		RegisterUsageRoutes(engine, storageManager, Controllers.NewUsageController(meteringService))
+	}
+
+	// 计费路由
+	// 订阅了套餐的用户使用套餐中的用量限额；高级功能（异常检测告警规则、长期指标查询）通过全局计费服务检查
+	if billingConfig := Config.GetBillingConfig(); billingConfig != nil && billingConfig.Enabled {
+		if db := Database.GetDB(); db != nil {
+			billingService := Services.NewBillingService(db, billingConfig, nil)
+			billingService.SetMeteringService(meteringService)
+			Services.SetDefaultBillingService(billingService)
+			RegisterBillingRoutes(engine, storageManager, Controllers.NewBillingController(billingService))
+		}
+	}
+
+	// 组织和团队路由
+	// 在邮件发送队列之后初始化，使邀请邮件走发送队列；仪表板、告警规则和API密钥的权限检查使用全局团队服务
+	if db := Database.GetDB(); db != nil {
+		teamService := Services.NewTeamService(db, Config.GetTeamsConfig(), nil)
+		Services.SetDefaultTeamService(teamService)
+		RegisterTeamRoutes(engine, Controllers.NewTeamController(teamService))
+	}
+
+	// 用户和角色批量操作路由（仅管理员）
+	// 导入、角色分配、启用/禁用作为后台任务执行，角色变更和禁用后撤销用户已签发的token
+	if db := Database.GetDB(); db != nil {
+		bulkService := Services.NewBulkOperationService(db, Config.GetBulkConfig())
+		if globalConfig := Config.GetConfig(); globalConfig != nil {
+			bulkService.SetTokenBlacklistService(newRedisTokenBlacklistService(globalConfig.Redis))
+		}
+		background.add("bulk_operations", func() {
+			bulkService.Start()
+		})
+		RegisterBulkRoutes(engine, storageManager, Controllers.NewBulkController(bulkService))
+	}
+
+	// 审计日志查询路由（仅管理员）
+	if db := Database.GetDB(); db != nil {
+		RegisterAuditRoutes(engine, storageManager, Controllers.NewAuditLogController(Services.NewAuditService(db)))
+	}
+
+	// 代码生成器注册的资源路由（仅管理员）
+	// cloudctl make:controller --resource 生成的路由注册插入到标记行之前
+	if db := Database.GetDB(); db != nil {
+		// cloudctl:routes
+	}
+
+	// 短信模板、送达回执和短信MFA验证路由
+	// 需在安全防护之前初始化全局短信服务，使自动响应的通知动作可以发送短信告警
+	if db := Database.GetDB(); db != nil {
+		if globalConfig := Config.GetConfig(); globalConfig != nil && globalConfig.SMS.IsConfigured() {
+			smsService := Services.NewSMSService(db, &globalConfig.SMS)
+			Services.SetDefaultSMSService(smsService)
+			smsController := Controllers.NewSMSController(smsService, globalConfig.SMS.WebhookSecret)
+			smsController.SetTokenBlacklistService(newRedisTokenBlacklistService(globalConfig.Redis))
+			RegisterSMSRoutes(engine, storageManager, smsController)
+		}
+	}
+
+	// 告警通知通道路由（仅管理员）
+	// 监控核心的通知管道、自动响应和测试发送共用同一组通道实例，使Telegram、企业微信的限流计数一致；短信通道依赖上面初始化的全局短信服务
+	monitoringCore := monitoringService.MonitoringCore()
+	var notificationChannels []Services.NotificationChannel
+	if globalConfig := Config.GetConfig(); globalConfig != nil {
+		notificationChannels = newMonitoringNotificationChannels(&globalConfig.Monitoring)
+	}
+	for _, channel := range notificationChannels {
+		monitoringCore.Pipeline().AddChannel(channel)
+	}
+	if logManager != nil {
+		if err := monitoringCore.RegisterCollector(logManager.Collector()); err != nil {
+			log.Printf("注册日志队列指标采集器失败: %v", err)
+		}
+	}
+	if modelCache != nil {
+		if err := monitoringCore.RegisterCollector(modelCache.Collector()); err != nil {
+			log.Printf("注册模型缓存指标采集器失败: %v", err)
+		}
+	}
+	if err := monitoringCore.RegisterCollector(cleanupScheduler.Collector()); err != nil {
+		log.Printf("注册数据清理指标采集器失败: %v", err)
+	}
+	if db := Database.GetDB(); db != nil {
+		if trashConfig := Config.GetTrashConfig(); trashConfig != nil && trashConfig.IntegrityInterval > 0 {
+			err := monitoringCore.RegisterCollectorWithOptions(
+				Services.DefaultDeletionService(db).IntegrityCollector(),
+				Services.CollectorOptions{Interval: trashConfig.IntegrityInterval, Timeout: time.Minute},
+			)
+			if err != nil {
+				log.Printf("注册孤儿数据检查采集器失败: %v", err)
+			}
+		}
+	}
+	if storageConfig := Config.GetStorageConfig(); storageConfig != nil && storageConfig.RestoreDrillEnabled {
+		// 定时恢复演练只在服务进程中运行；自动备份仍由应用容器中的备份服务负责
+		backupConfig := Services.NewBackupConfigFromStorage(storageConfig)
+		backupConfig.EnableAutoBackup = false
+		backupService := Services.NewBackupService(storageManager, backupConfig)
+		if err := monitoringCore.RegisterCollector(backupService.Collector()); err != nil {
+			log.Printf("注册备份恢复演练指标采集器失败: %v", err)
+		}
+		for _, rule := range backupService.AlertRules() {
+			if err := monitoringCore.AddRule(rule); err != nil {
+				log.Printf("添加备份恢复演练告警规则失败: %v", err)
+			}
+		}
+	}
+	var certificateCollector *Services.CertificateExpiryCollector
+	if globalConfig := Config.GetConfig(); globalConfig != nil {
+		certificateCollector = registerMonitoringCollectors(monitoringCore, &globalConfig.Monitoring)
+	}
+	monitoringController.SetNotificationChannels(notificationChannels)
+	notificationChannelGroup := v1.Group("/monitoring/notification-channels")
+	notificationChannelGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	notificationChannelGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+	notificationChannelGroup.GET("", monitoringController.GetNotificationChannels)
+	notificationChannelGroup.POST("/:channel/test", monitoringController.TestNotificationChannel)
+
+	// 证书和域名到期状态路由（仅管理员），启用证书采集器时注册
+	if certificateCollector != nil {
+		monitoringController.SetCertificateCollector(certificateCollector)
+		certificateGroup := v1.Group("/monitoring/certificates")
+		certificateGroup.Use(Middleware.NewAuthMiddleware().Handle())
+		certificateGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+		certificateGroup.GET("", monitoringController.GetCertificates)
+	}
+
+	// 指标历史和告警规则回测路由（仅管理员）
+	// 监控服务批量处理指标时写入历史采样，监控核心的动态阈值规则和回测按时间范围查询
+	// 启用批量写入时采样经写入队列按批插入，可选先写入Redis列表
+	var metricHistory *Services.MetricHistoryService
+	var metricWriter *Services.MetricBatchWriter
+	if db := Database.GetDB(); db != nil {
+		metricHistory = Services.NewMetricHistoryService(db, nil)
+		cleanupScheduler.Register("metric_samples", "清理超过保留时间的指标历史采样", 24*time.Hour, metricHistory.Cleanup)
+		monitoringService.SetMetricHistoryService(metricHistory)
+		if globalConfig := Config.GetConfig(); globalConfig != nil && globalConfig.Monitoring.StorageConfig.Batch.Enabled {
+			metricWriter = Services.NewMetricBatchWriter(metricHistory, &globalConfig.Monitoring)
+			metricWriter.SetMonitoringCore(monitoringCore)
+			if redisConfig := Config.GetRedisConfig(); globalConfig.Monitoring.StorageConfig.Batch.RedisWriteBehind && redisConfig != nil && redisConfig.IsConfigured() {
+				metricWriter.SetRedisWriteBehind(Services.NewRedisUniversalClient(redisConfig), globalConfig.Monitoring.StorageConfig.Redis.KeyPrefix+"metric_write_queue")
+			}
+			background.add("metric_writer", func() {
+				metricWriter.Start()
+			})
+			monitoringService.SetMetricBatchWriter(metricWriter)
+		}
+		monitoringCore.SetMetricHistory(metricHistory)
+		monitoringController.SetAlertService(monitoringCore.AlertEngine())
+
+		alertRuleGroup := v1.Group("/monitoring/alert-rules")
+		alertRuleGroup.Use(Middleware.NewAuthMiddleware().Handle())
+		alertRuleGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+		alertRuleGroup.POST("/backtest", monitoringController.BacktestAlertRule)
+
+		// 告警规则管理路由（需要认证）
+		// 可见性、团队归属和修改权限由控制器按规则检查，更新使用版本号乐观锁，删除需要另一名管理员审批
+		alertRuleCrudGroup := v1.Group("/monitoring/alert-rules")
+		alertRuleCrudGroup.Use(Middleware.NewAuthMiddleware().Handle())
+		alertRuleCrudGroup.GET("", monitoringController.GetAlertRules)
+		alertRuleCrudGroup.POST("", monitoringController.CreateAlertRule)
+		alertRuleCrudGroup.PUT("/:id", monitoringController.UpdateAlertRule)
+		alertRuleCrudGroup.DELETE("/:id", Middleware.NewApprovalMiddleware(approvalService).Require(Config.ApprovalActionAlertRuleDelete), monitoringController.DeleteAlertRule)
+
+		// 监控仪表板路由，组件数据从监控核心和指标历史查询
+		dashboardService := Services.NewMonitoringDashboardService(db)
+		dashboardService.SetMonitoringCore(monitoringCore)
+		dashboardService.SetMetricHistory(metricHistory)
+		dashboardService.SetStaleAfter(monitoringService.StaleAfter())
+		RegisterMonitoringDashboardRoutes(engine, Controllers.NewMonitoringDashboardController(dashboardService))
+
+		// 服务等级目标路由，达成率和消耗速率按评估间隔上报到监控核心，消耗告警由统一告警引擎评估
+		var sloService *Services.MonitoringSLOService
+		if globalConfig := Config.GetConfig(); globalConfig != nil && globalConfig.Monitoring.SLO.Enabled {
+			sloService = Services.NewMonitoringSLOService(db, &globalConfig.Monitoring)
+			sloService.SetMonitoringCore(monitoringCore)
+			sloService.SetMetricHistory(metricHistory)
+			background.add("monitoring_slo", func() {
+				sloService.StartEvaluator(context.Background())
+			})
+			RegisterMonitoringSLORoutes(engine, storageManager, Controllers.NewMonitoringSLOController(sloService))
+		}
+
+		// 评论路由，告警、安全事件和监控报告的评论，告警ID从监控核心校验
+		commentService := Services.NewCommentService(db)
+		commentService.SetMonitoringCore(monitoringCore)
+		RegisterCommentRoutes(engine, Controllers.NewCommentController(commentService))
+
+		// 事件路由，告警引擎触发和恢复的告警按规则归入事件，记录时间线并统计MTTA/MTTR，复盘附带告警评论
+		if globalConfig := Config.GetConfig(); globalConfig != nil && globalConfig.Monitoring.Incidents.Enabled {
+			incidentService := Services.NewMonitoringIncidentService(db, &globalConfig.Monitoring)
+			incidentService.Attach(monitoringCore)
+			incidentService.SetCommentService(commentService)
+			RegisterMonitoringIncidentRoutes(engine, storageManager, Controllers.NewMonitoringIncidentController(incidentService))
+		}
+
+		// 告警静默路由，通知管道发送前按告警标签匹配静默，维护窗口按计划自动创建关联的静默
+		if globalConfig := Config.GetConfig(); globalConfig != nil && globalConfig.Monitoring.Silences.Enabled {
+			silenceService := Services.NewAlertSilenceService(db, &globalConfig.Monitoring)
+			silenceService.Attach(monitoringCore.Pipeline())
+			background.add("monitoring_silences", func() {
+				silenceService.Start(context.Background())
+			})
+			RegisterMonitoringSilenceRoutes(engine, storageManager, Controllers.NewMonitoringSilenceController(silenceService))
+		}
+
+		// 定时监控报告路由（仅管理员），调度在后台按检查间隔运行，邮件投递使用全局邮件服务
+		if globalConfig := Config.GetConfig(); globalConfig != nil && globalConfig.Monitoring.Reports.Enabled {
+			reportService := Services.NewMonitoringReportService(db, &globalConfig.Monitoring)
+			reportService.SetMonitoringCore(monitoringCore)
+			reportService.SetMetricHistory(metricHistory)
+			if sloService != nil {
+				reportService.SetSLOService(sloService)
+			}
+			background.add("monitoring_reports", func() {
+				reportService.StartScheduler(context.Background())
+			})
+			cleanupScheduler.Register("monitoring_report_files", "清理超过保留时间的监控报告文件", 0, func(ctx context.Context) (int64, error) {
+				return reportService.Cleanup(ctx, time.Now())
+			})
+			RegisterMonitoringReportRoutes(engine, storageManager, Controllers.NewMonitoringReportController(reportService))
+		}
+
+		// 数据导出路由，告警、登录尝试和安全事件导出为CSV/XLSX，告警附带评论记录；定时导出通过邮件发送
+		exportConfig := Config.GetExportConfig()
+		exportService := Services.NewDataExportService(db, exportConfig)
+		exportService.SetMonitoringCore(monitoringCore)
+		exportService.SetCommentService(commentService)
+		background.add("export_scheduler", func() {
+			if exportConfig != nil && exportConfig.ScheduleEnabled {
+				exportService.StartScheduler(context.Background())
+			}
+		})
+		RegisterExportRoutes(engine, storageManager, Controllers.NewExportController(exportService))
+
+		// 备份管理路由（仅管理员），按表或领域备份和恢复使用当前数据库连接；自动备份和恢复演练不在此实例中运行
+		// 定时备份的存储位置为 archive 时上传到 ARCHIVE_STORE 配置的归档存储
+		if storageConfig := Config.GetStorageConfig(); storageConfig != nil {
+			backupConfig := Services.NewBackupConfigFromStorage(storageConfig)
+			backupConfig.EnableAutoBackup = false
+			backupConfig.RestoreDrillEnabled = false
+			backupService := Services.NewBackupService(storageManager, backupConfig)
+			backupService.SetDB(db)
+			scheduleService := Services.NewBackupScheduleService(db, backupService, storageConfig)
+			if archiveConfig := Config.GetArchiveConfig(); archiveConfig != nil {
+				if archiveStore, err := Services.NewArchiveObjectStore(archiveConfig); err == nil {
+					scheduleService.SetObjectStore(archiveStore)
+				}
+			}
+			background.add("backup_schedules", func() {
+				if storageConfig.BackupSchedulesEnabled {
+					scheduleService.StartScheduler(context.Background())
+				}
+			})
+			backupController := Controllers.NewBackupController(backupService)
+			backupController.SetScheduleService(scheduleService)
+			RegisterBackupRoutes(engine, storageManager, backupController)
+		}
+
+		// 配置快照路由（仅管理员），启动时配置与本环境上一个快照不同则自动生成快照，记录每次部署的配置
+		configSnapshotService := Services.NewConfigSnapshotService(db)
+		background.add("config_snapshot", func() {
+			if _, err := configSnapshotService.CaptureIfChanged("启动时自动生成"); err != nil {
+				log.Printf("生成启动配置快照失败: %v", err)
+			}
+		})
+		RegisterConfigSnapshotRoutes(engine, storageManager, Controllers.NewConfigSnapshotController(configSnapshotService))
+	}
+
+	// 外部指标推送路由
+	// 使用来源令牌认证，不经过用户认证中间件；指标历史可用时同时写入历史
+	var ingestService *Services.MetricIngestService
+	if globalConfig := Config.GetConfig(); globalConfig != nil && globalConfig.Monitoring.Ingest.Enabled {
+		ingestService = Services.NewMetricIngestService(&globalConfig.Monitoring)
+		ingestService.SetMonitoringCore(monitoringCore)
+		ingestService.SetCardinalityGuard(monitoringService.CardinalityGuard())
+		if metricWriter != nil {
+			ingestService.SetMetricBatchWriter(metricWriter)
+		} else if metricHistory != nil {
+			ingestService.SetMetricHistory(metricHistory)
+		}
+		monitoringController.SetMetricIngestService(ingestService)
+		v1.POST("/monitoring/ingest", monitoringController.IngestMetrics)
+	}
+
+	// 外部告警接入路由
+	// Alertmanager 等外部监控系统使用来源令牌推送告警，不经过用户认证中间件；告警进入监控核心的告警引擎，与规则告警共用通知和事件流程
+	if globalConfig := Config.GetConfig(); globalConfig != nil && globalConfig.Monitoring.AlertWebhook.Enabled {
+		alertWebhookService := Services.NewAlertWebhookService(&globalConfig.Monitoring)
+		alertWebhookService.SetMonitoringCore(monitoringCore)
+		monitoringController.SetAlertWebhookService(alertWebhookService)
+		v1.POST("/monitoring/alert-webhooks/alertmanager", monitoringController.ReceiveAlertmanagerWebhook)
+		v1.POST("/monitoring/alert-webhooks/json", monitoringController.ReceiveJSONAlertWebhook)
+	}
+
+	// Kubernetes集成
+	// Pod、命名空间和节点作为部署标签附加到指标和日志；就绪检查结果同步到Pod的readiness gate条件；
+	// 配置的业务指标（如队列深度）通过外部指标API提供给HPA
+	if globalConfig := Config.GetConfig(); globalConfig != nil && globalConfig.Monitoring.Kubernetes.Enabled {
+		kubernetesIntegration := Services.NewKubernetesIntegration(&globalConfig.Monitoring)
+		kubernetesIntegration.SetMonitoringCore(monitoringCore)
+		labels := kubernetesIntegration.Labels()
+		monitoringService.SetDefaultTags(labels)
+		logFields := make(map[string]interface{}, len(labels))
+		for key, value := range labels {
+			logFields[key] = value
+		}
+		logManager.SetStaticFields(logFields)
+
+		// 监控核心完成首次采集前不接收流量，避免HPA和告警读到空指标
+		healthController.AddReadinessGate("monitoring", func() error {
+			if len(monitoringCore.Values()) == 0 {
+				return fmt.Errorf("监控指标尚未采集")
+			}
+			return nil
+		})
+		kubernetesIntegration.SetReadinessProbe(healthController.ReadinessError)
+		background.add("kubernetes_readiness", func() {
+			kubernetesIntegration.StartReadinessSync(context.Background(), globalConfig.Monitoring.Kubernetes.ReadinessSyncInterval)
+		})
+		RegisterKubernetesRoutes(engine, storageManager, Controllers.NewKubernetesController(kubernetesIntegration))
+	}
+
+	// 大表分区和归档路由（仅管理员）
+	// monitoring_metrics、login_attempts、security_events 按月分区，超过在线保留时间的分区归档到本地目录或对象存储
+	var archiveService *Services.TableArchiveService
+	if db := Database.GetDB(); db != nil {
+		if archiveConfig := Config.GetArchiveConfig(); archiveConfig != nil && archiveConfig.Enabled {
+			archiveStore, err := Services.NewArchiveObjectStore(archiveConfig)
+			if err != nil {
+				logManager.LogBusiness(context.Background(), "archive", "store_init_failed", "归档存储初始化失败", map[string]interface{}{
+					"error": err.Error(),
+				})
+			} else {
+				archiveService = Services.NewTableArchiveService(db, archiveConfig, archiveStore)
+				background.add("table_archive", func() {
+					archiveService.Start(context.Background())
+				})
+				RegisterArchiveRoutes(engine, storageManager, Controllers.NewArchiveController(archiveService))
+			}
+		}
+	}
+
+	// 安全防护路由
+	// 威胁情报导出、Webhook共享和自动响应依赖数据库，数据库未初始化时不注册
+	var securityService *Services.SecurityService
+	if db := Database.GetDB(); db != nil {
+		securityConfig := &Config.SecurityConfig{}
+		securityConfig.SetDefaults()
+		if globalConfig := Config.GetConfig(); globalConfig != nil {
+			securityConfig = &globalConfig.Security
+		}
+		securityController := Controllers.NewSecurityController()
+		securityService = Services.NewSecurityService(db, securityConfig)
+		// 自动响应的通知动作使用监控告警通知通道；强制下线标记与认证中间件共用Redis
+		if globalConfig := Config.GetConfig(); globalConfig != nil {
+			responseService := securityService.GetResponseService()
+			for _, channel := range notificationChannels {
+				responseService.AddNotificationChannel(channel)
+			}
+			if globalConfig.Redis.IsConfigured() {
+				responseService.SetTokenBlacklistService(newRedisTokenBlacklistService(globalConfig.Redis))
+			}
+		}
+		securityController.SetSecurityService(securityService)
+		// 登录尝试通过安全服务记录，登录历史和新设备提醒使用同一份记录
+		loginHistoryService := Services.NewLoginHistoryService(db, &securityConfig.LoginNotification, nil)
+		loginHistoryService.SetSecurityService(securityService)
+		Services.SetDefaultLoginHistoryService(loginHistoryService)
+		if archiveService != nil {
+			securityController.SetTableArchiveService(archiveService)
+		}
+		sharingService := Services.NewThreatIntelSharingService(db, &securityConfig.ThreatIntelSharing, nil)
+		background.add("threat_intel_sharing", func() {
+			if err := sharingService.Start(); err != nil {
+				logManager.LogBusiness(context.Background(), "security", "threat_intel_sharing_start_failed", "威胁情报共享服务启动失败", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+		})
+		securityController.SetThreatIntelSharingService(sharingService)
+		RegisterSecurityRoutes(engine, storageManager, securityController)
+
+		// CSP违规报告，浏览器按安全响应头中的 report-uri 上报，保存为安全事件
+		RegisterCSPReportRoutes(engine, Controllers.NewCSPReportController(Services.NewCSPReportService(db), securityConfig.Headers.CSPReportMaxBytes))
+
+		// 安全态势评分，定期保存评分快照用于趋势展示
+		if securityConfig.Posture.Enabled {
+			postureService := Services.NewSecurityPostureService(db, securityConfig)
+			background.add("security_posture", func() {
+				postureService.Start(context.Background())
+			})
+			cleanupScheduler.Register("security_posture_snapshots", "清理超过保留时间的安全评分快照", 0, postureService.Cleanup)
+			RegisterSecurityPostureRoutes(engine, storageManager, Controllers.NewSecurityPostureController(postureService))
+		}
+
+		// 漏洞扫描，定期执行扫描插件，CORS检查直接探测当前路由引擎
+		if securityConfig.Scan.Enabled {
+			auditService := Services.NewSecurityAuditService(storageManager)
+			auditService.SetScanners(Services.DefaultVulnerabilityScanners(Config.GetConfig(), db, engine))
+			cleanupScheduler.Register("security_scan_results", "清理超过保留时间的漏洞扫描结果", 0, auditService.CleanupScanResults)
+			RegisterSecurityScanRoutes(engine, storageManager, Controllers.NewSecurityScanController(auditService))
+		}
+
+		// 安全数据归档，审计日志、安全事件和登录尝试超过保留时间后先导出到归档存储再删除，已由大表分区归档管理的表跳过
+		if securityConfig.Archive.Enabled {
+			if archiveConfig := Config.GetArchiveConfig(); archiveConfig != nil {
+				if archiveStore, err := Services.NewArchiveObjectStore(archiveConfig); err == nil {
+					securityArchive := Services.NewSecurityArchiveService(db, &securityConfig.Archive, archiveStore)
+					if archiveService != nil {
+						securityArchive.ExcludeTables(archiveService.Tables()...)
+					}
+					cleanupScheduler.Register("security_archive", "归档并删除超过保留时间的审计日志和安全事件", 0, securityArchive.Archive)
+					RegisterSecurityArchiveRoutes(engine, storageManager, Controllers.NewSecurityArchiveController(securityArchive))
+				} else {
+					log.Printf("安全数据归档存储初始化失败: %v", err)
+				}
+			}
+		}
+
+		// 软件物料清单，启用OSV时定期检查依赖漏洞，新发现的漏洞记录为安全事件
+		var dependencyService *Services.DependencyVulnerabilityService
+		if securityConfig.Scan.Enabled && securityConfig.Scan.OSVEnabled {
+			if document, err := SBOM.Load(); err == nil {
+				dependencyService = Services.NewDependencyVulnerabilityService(db, &securityConfig.Scan, document)
+				background.add("dependency_scan", func() {
+					dependencyService.Start(context.Background())
+				})
+			} else {
+				log.Printf("加载软件物料清单失败: %v", err)
+			}
+		}
+		RegisterSBOMRoutes(engine, storageManager, Controllers.NewSBOMController(dependencyService))
+
+		// 密码过期提醒邮件，每小时检查一次即将过期的密码
+		background.add("password_expiry", func() {
+			Services.NewPasswordExpiryService(db, &securityConfig.BaseSecurity, nil).StartNotifier(context.Background(), time.Hour)
+		})
+	}
+
+	// 内部gRPC服务
+	// 双向TLS认证的内部微服务通过gRPC推送指标、查询告警和上报安全事件，与REST接口共用服务实例
+	if grpcConfig := Config.GetGrpcConfig(); grpcConfig != nil && grpcConfig.Enabled {
+		grpcServer := Grpc.NewServer(*grpcConfig)
+		grpcServer.Use(
+			Grpc.TracingInterceptor(),
+			Grpc.LoggingInterceptor(),
+			Grpc.NewMetricsInterceptor(monitoringCore).Interceptor(),
+		)
+		Grpc.NewInternalServices(ingestService, monitoringService, securityService).Register(grpcServer)
+		background.add("grpc", func() {
+			if err := grpcServer.Start(); err != nil {
+				logManager.LogBusiness(context.Background(), "grpc", "start_failed", "gRPC服务启动失败", map[string]interface{}{
+					"error": err.Error(),
+				})
+			} else {
+				Grpc.SetDefaultServer(grpcServer)
+			}
+		})
+	}
+
+	// 全部清理任务注册完成后启动清理调度器
+	background.add("cleanup", func() {
+		cleanupScheduler.Start(context.Background())
+	})
+
+	// 启动时写入接口文档，包含全部路由
+	if openAPIConfig != nil && openAPIConfig.OutputFile != "" {
+		if err := apiRegistry.WriteFile(openAPIConfig.OutputFile, engine.Routes()); err != nil {
+			logManager.LogBusiness(context.Background(), "openapi", "write_failed", "接口文档写入失败", map[string]interface{}{
+				"file":  openAPIConfig.OutputFile,
+				"error": err.Error(),
+			})
+		}
+	}
+
 	// 记录路由注册完成日志
 	// 使用 business 日志记录器，因为路由注册属于业务逻辑
 	logManager.LogBusiness(context.Background(), "routes", "register", "所有路由注册完成", map[string]interface{}{
@@ -281,4 +1314,86 @@ func RegisterRoutes(engine *gin.Engine, storageManager *Storage.StorageManager,
 		"api_version":  "v1",
 		"base_path":    "/api/v1",
 	})
+
+	return background
+}
+
+// newMonitoringNotificationChannels 根据监控通知配置创建告警通知通道
+// 未启用的通道也会注册，发送时由调用方按 IsEnabled 跳过，测试发送时返回未启用
+func newMonitoringNotificationChannels(config *Config.MonitoringConfig) []Services.NotificationChannel {
+	channels := []Services.NotificationChannel{
+		Services.NewWebhookNotificationChannel(config, nil),
+		Services.NewTelegramNotificationChannel(config, nil),
+		Services.NewWeChatWorkNotificationChannel(config, nil),
+	}
+	if config.NotificationConfig.SMS.Enabled && Services.DefaultSMSService() != nil {
+		channels = append(channels, Services.NewSMSNotificationChannel(config, nil))
+	}
+	return channels
+}
+
+// registerMonitoringCollectors 按监控配置向监控核心注册内置的外部指标采集器
+// 注册失败只记录日志，不影响应用启动；返回注册成功的证书到期采集器，未启用时为 nil
+func registerMonitoringCollectors(core *Services.MonitoringCore, config *Config.MonitoringConfig) *Services.CertificateExpiryCollector {
+	core.SetDefaultCollectorTimeout(config.Collectors.DefaultTimeout)
+
+	if nginx := config.Collectors.Nginx; nginx.Enabled {
+		err := core.RegisterCollectorWithOptions(
+			Services.NewNginxStubStatusCollector(nginx.StatusURL),
+			Services.CollectorOptions{Interval: nginx.Interval, Timeout: nginx.Timeout},
+		)
+		if err != nil {
+			log.Printf("注册nginx指标采集器失败: %v", err)
+		}
+	}
+
+	// 容器资源采集器注册在运行时采集器之后，覆盖其 cpu_usage、memory_usage；未运行在容器中时使用主机指标
+	if cgroup := config.Collectors.Cgroup; cgroup.Enabled {
+		collector, err := Services.NewCgroupMetricsCollector(cgroup.Path)
+		if err != nil {
+			log.Printf("未启用容器资源指标: %v", err)
+		} else if err := core.RegisterCollector(collector); err != nil {
+			log.Printf("注册容器资源指标采集器失败: %v", err)
+		} else {
+			log.Printf("已识别cgroup %s，CPU和内存使用率按容器限额计算", collector.Version())
+		}
+	}
+
+	certificates := config.Collectors.Certificates
+	if !certificates.Enabled {
+		return nil
+	}
+	collector, err := Services.NewCertificateExpiryCollectorFromConfig(config)
+	if err != nil {
+		log.Printf("注册证书到期采集器失败: %v", err)
+		return nil
+	}
+	err = core.RegisterCollectorWithOptions(collector, Services.CollectorOptions{Interval: certificates.Interval, Timeout: certificates.Timeout})
+	if err != nil {
+		log.Printf("注册证书到期采集器失败: %v", err)
+		return nil
+	}
+	for _, rule := range collector.AlertRules() {
+		if err := core.AddRule(rule); err != nil {
+			log.Printf("添加证书到期告警规则失败: rule=%s, error=%v", rule.ID, err)
+		}
+	}
+	return collector
+}
+
+// newRedisTokenBlacklistService 创建与认证中间件共用Redis的黑名单服务，Redis未配置或不可用时只使用内存
+func newRedisTokenBlacklistService(redisConfig Config.RedisConfig) *Services.TokenBlacklistService {
+	if !redisConfig.IsConfigured() {
+		return Services.NewTokenBlacklistService(nil)
+	}
+	redisService := Services.NewRedisService(&Services.RedisConfig{
+		Host:     redisConfig.Host,
+		Port:     redisConfig.Port,
+		Password: redisConfig.Password,
+		DB:       redisConfig.GetDB(),
+	})
+	if err := redisService.Ping(); err != nil {
+		return Services.NewTokenBlacklistService(nil)
+	}
+	return Services.NewTokenBlacklistService(redisService)
 }
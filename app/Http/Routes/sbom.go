@@ -0,0 +1,43 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/SBOM"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterSBOMRoutes 注册软件物料清单和依赖漏洞路由，所有路由需要管理员权限
+func RegisterSBOMRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.SBOMController) {
+	sbomGroup := router.Group("/api/v1/system/sbom")
+	sbomGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	sbomGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+
+	api := OpenAPI.DefaultRegistry().Group(sbomGroup, "安全防护", OpenAPI.BearerAuth)
+	{
+		api.GET("", OpenAPI.Route{
+			Summary:     "获取软件物料清单",
+			Description: "模块和版本取自二进制的构建信息，许可证取自构建时内嵌的清单；licenses 为按许可证统计的模块数量；format=cyclonedx 时返回 CycloneDX 1.5 文档（不使用统一响应格式）",
+			Query:       Controllers.SBOMQuery{},
+			Response:    SBOM.Document{},
+		}, controller.GetSBOM)
+		api.GET("/vulnerabilities", OpenAPI.Route{
+			Summary:  "获取依赖漏洞",
+			Query:    Controllers.DependencyVulnerabilityQuery{},
+			Response: []Models.DependencyVulnerability{},
+			Errors:   []int{http.StatusServiceUnavailable},
+		}, controller.GetDependencyVulnerabilities)
+		api.POST("/vulnerabilities/check", OpenAPI.Route{
+			Summary:     "立即检查依赖漏洞",
+			Description: "用依赖清单查询OSV漏洞库，新发现的漏洞记录为 dependency_vulnerability 安全事件，不再被报告的漏洞标记为已解决",
+			Response:    Services.DependencyCheckResult{},
+			Errors:      []int{http.StatusBadGateway, http.StatusServiceUnavailable},
+		}, controller.CheckDependencyVulnerabilities)
+	}
+}
@@ -1,17 +1,23 @@
 package Routes
 
 import (
+	"cloud-platform-api/app/Config"
 	"cloud-platform-api/app/Http/Controllers"
 	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Storage"
 
 	"github.com/gin-gonic/gin"
 )
 
 // RegisterSecurityRoutes 注册安全防护路由
-func RegisterSecurityRoutes(router *gin.Engine, controller *Controllers.SecurityController) {
-	// 安全防护路由组，需要认证
+func RegisterSecurityRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.SecurityController) {
+	// 自助解锁路由，用户被锁定后无法登录，通过邮件中的签名链接访问，不需要认证
+	router.GET("/api/v1/security/unlock", controller.SelfServiceUnlock)
+
+	// 安全防护路由组，需要认证和管理员权限
 	securityGroup := router.Group("/api/v1/security")
 	securityGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	securityGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
 	{
 		// 安全事件相关路由
 		securityGroup.GET("/events", controller.GetSecurityEvents)
@@ -19,14 +25,35 @@ func RegisterSecurityRoutes(router *gin.Engine, controller *Controllers.Security
 		// 威胁情报相关路由
 		securityGroup.GET("/threats", controller.GetThreatIntelligence)
 
+		// 威胁情报导出和共享路由
+		securityGroup.GET("/threats/export", controller.ExportThreatIntelligence)
+		securityGroup.GET("/threats/share", controller.GetThreatIntelSharingStatus)
+		securityGroup.POST("/threats/share", controller.ShareThreatIntelligence)
+
+		// 自动响应剧本和执行记录路由
+		responseGroup := securityGroup.Group("/responses")
+		responseGroup.GET("/playbooks", controller.GetResponsePlaybooks)
+		responseGroup.GET("/executions", controller.GetResponseExecutions)
+		responseGroup.POST("/executions/:id/approve", controller.ApproveResponseExecution)
+		responseGroup.POST("/executions/:id/reject", controller.RejectResponseExecution)
+
 		// 登录尝试相关路由
 		securityGroup.GET("/login-attempts", controller.GetLoginAttempts)
 
-		// 账户锁定相关路由
-		securityGroup.GET("/account-lockouts", controller.GetAccountLockouts)
-		// 账户管理
-		// TODO: 实现账户解锁功能
-		// securityGroup.POST("/account-lockouts/:id/unlock", controller.UnlockAccount)
+		// 账户锁定管理路由，解锁管理员账户需要双人审批
+		approval := Middleware.NewApprovalMiddleware(nil)
+		lockoutGroup := securityGroup.Group("/account-lockouts")
+		lockoutGroup.GET("", controller.GetAccountLockouts)
+		lockoutGroup.GET("/stats", controller.GetAccountLockoutStatistics)
+		lockoutGroup.POST("/unlock", approval.RequireWhen(Config.ApprovalActionAdminUnlock, controller.UnlockAffectsAdmin), controller.UnlockAccountsBy)
+		lockoutGroup.POST("/:id/unlock", approval.RequireWhen(Config.ApprovalActionAdminUnlock, controller.UnlockAffectsAdmin), controller.UnlockAccount)
+
+		// 访问控制条目管理路由，更新支持 If-Match 乐观锁，创建和更新需要双人审批
+		accessControlGroup := securityGroup.Group("/access-controls")
+		accessControlGroup.GET("", controller.GetAccessControls)
+		accessControlGroup.POST("", approval.Require(Config.ApprovalActionSecurityConfig), controller.CreateAccessControl)
+		accessControlGroup.GET("/:id", controller.GetAccessControl)
+		accessControlGroup.PUT("/:id", approval.Require(Config.ApprovalActionSecurityConfig), controller.UpdateAccessControl)
 
 		// 安全告警相关路由
 		securityGroup.GET("/alerts", controller.GetSecurityAlerts)
@@ -0,0 +1,36 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterSecurityArchiveRoutes 注册安全数据归档路由，所有路由需要管理员权限
+func RegisterSecurityArchiveRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.SecurityArchiveController) {
+	archiveGroup := router.Group("/api/v1/security/archive")
+	archiveGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	archiveGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+
+	api := OpenAPI.DefaultRegistry().Group(archiveGroup, "安全防护", OpenAPI.BearerAuth)
+	{
+		api.GET("/files", OpenAPI.Route{
+			Summary:     "获取安全数据归档文件列表",
+			Description: "返回归档索引中与时间范围重叠的文件，按记录时间倒序",
+			Query:       Controllers.SecurityArchiveFileQuery{},
+			Errors:      []int{http.StatusNotFound},
+		}, controller.GetArchiveFiles)
+		api.GET("/search", OpenAPI.Route{
+			Summary:     "搜索安全数据归档",
+			Description: "按表、时间范围、字段和关键字搜索归档文件中的记录，最多读取 SECURITY_ARCHIVE_MAX_SEARCH_FILES 个文件；文件与索引中的校验值不一致时返回409",
+			Query:       Controllers.SecurityArchiveSearchQuery{},
+			Response:    Services.SecurityArchiveSearchResult{},
+			Errors:      []int{http.StatusNotFound, http.StatusConflict},
+		}, controller.SearchArchive)
+	}
+}
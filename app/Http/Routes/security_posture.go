@@ -0,0 +1,35 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterSecurityPostureRoutes 注册安全态势评分路由，所有路由需要管理员权限
+func RegisterSecurityPostureRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.SecurityPostureController) {
+	postureGroup := router.Group("/api/v1/security/posture")
+	postureGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	postureGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+
+	api := OpenAPI.DefaultRegistry().Group(postureGroup, "安全防护", OpenAPI.BearerAuth)
+	{
+		api.GET("", OpenAPI.Route{
+			Summary:     "获取安全态势评分",
+			Description: "汇总MFA普及率、闲置API密钥、严重漏洞、密码策略合规率和未处理的高风险事件，总分为可用分项按权重的加权平均；附带评分快照趋势",
+			Query:       Controllers.SecurityPostureQuery{},
+			Response:    Services.SecurityPosture{},
+		}, controller.GetSecurityPosture)
+		api.POST("/snapshots", OpenAPI.Route{
+			Summary:  "保存安全态势评分快照",
+			Response: Models.SecurityPostureSnapshot{},
+			Status:   http.StatusCreated,
+		}, controller.CreateSecurityPostureSnapshot)
+	}
+}
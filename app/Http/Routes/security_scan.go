@@ -0,0 +1,41 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Storage"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterSecurityScanRoutes 注册漏洞扫描路由，所有路由需要管理员权限
+func RegisterSecurityScanRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.SecurityScanController) {
+	scanGroup := router.Group("/api/v1/security/scans")
+	scanGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	scanGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+
+	api := OpenAPI.DefaultRegistry().Group(scanGroup, "安全防护", OpenAPI.BearerAuth)
+	{
+		api.POST("", OpenAPI.Route{
+			Summary:     "执行漏洞扫描",
+			Description: "同步执行依赖漏洞（OSV）、TLS配置、JWT密钥强度、CORS策略、开放端口和API密钥过期检查；插件执行失败记录在 errors 中",
+			Response:    Models.SecurityScanResult{},
+			Status:      http.StatusCreated,
+			Errors:      []int{http.StatusConflict},
+		}, controller.CreateSecurityScan)
+		api.GET("", OpenAPI.Route{
+			Summary:  "获取扫描结果列表",
+			Query:    Controllers.SecurityScanListQuery{},
+			Response: []Models.SecurityScanResult{},
+		}, controller.GetSecurityScans)
+		api.GET("/:id", OpenAPI.Route{
+			Summary:  "获取扫描结果详情",
+			Params:   []OpenAPI.Param{{Name: "id", Description: "扫描ID"}},
+			Response: Models.SecurityScanResult{},
+			Errors:   []int{http.StatusNotFound},
+		}, controller.GetSecurityScan)
+	}
+}
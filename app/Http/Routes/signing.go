@@ -0,0 +1,63 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterSigningClientRoutes 注册请求签名客户端管理路由，所有路由需要管理员权限
+func RegisterSigningClientRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.SigningClientController) {
+	signingGroup := router.Group("/api/v1/admin/signing-clients")
+	signingGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	signingGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+
+	idParam := OpenAPI.PathID("id", "签名客户端ID")
+	api := OpenAPI.DefaultRegistry().Group(signingGroup, "请求签名", OpenAPI.BearerAuth)
+	{
+		api.GET("", OpenAPI.Route{
+			Summary:  "获取签名客户端列表",
+			Response: []Models.SigningClient{},
+		}, controller.GetSigningClients)
+		api.POST("", OpenAPI.Route{
+			Summary:     "创建签名客户端",
+			Description: "服务间调用使用返回的 key_id 和 secret 对请求签名，以 user_id 对应用户的身份执行；密钥只返回这一次",
+			Request:     Services.SigningClientInput{},
+			Response:    Controllers.SigningClientSecretResponse{},
+			Status:      http.StatusCreated,
+		}, controller.CreateSigningClient)
+		api.GET("/:id", OpenAPI.Route{
+			Summary:  "获取签名客户端",
+			Params:   []OpenAPI.Param{idParam},
+			Response: Models.SigningClient{},
+			Errors:   []int{http.StatusNotFound},
+		}, controller.GetSigningClient)
+		api.PUT("/:id", OpenAPI.Route{
+			Summary:     "更新签名客户端",
+			Description: "status 为 disabled 时该客户端的签名请求返回401",
+			Params:      []OpenAPI.Param{idParam},
+			Request:     Services.SigningClientInput{},
+			Response:    Models.SigningClient{},
+			Errors:      []int{http.StatusNotFound},
+		}, controller.UpdateSigningClient)
+		api.DELETE("/:id", OpenAPI.Route{
+			Summary: "删除签名客户端",
+			Params:  []OpenAPI.Param{idParam},
+			Errors:  []int{http.StatusNotFound},
+		}, controller.DeleteSigningClient)
+		api.POST("/:id/rotate-secret", OpenAPI.Route{
+			Summary:     "轮换签名密钥",
+			Description: "旧密钥在 grace_period 内仍然有效，未指定时使用 REQUEST_SIGNING_ROTATION_GRACE_PERIOD，为 0 时立即失效",
+			Params:      []OpenAPI.Param{idParam},
+			Request:     Controllers.RotateSigningSecretRequest{},
+			Response:    Controllers.SigningClientSecretResponse{},
+			Errors:      []int{http.StatusNotFound},
+		}, controller.RotateSigningSecret)
+	}
+}
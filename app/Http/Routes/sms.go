@@ -0,0 +1,35 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterSMSRoutes 注册短信路由
+func RegisterSMSRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.SMSController) {
+	// 服务商送达回执，由服务商调用，通过 token 参数校验，不需要认证
+	router.POST("/api/v1/sms/callbacks/:provider", controller.HandleCallback)
+
+	// 短信MFA验证，需要登录
+	mfaGroup := router.Group("/api/v1/auth/mfa/sms")
+	mfaGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	{
+		mfaGroup.POST("/send", controller.SendMFACode)
+		mfaGroup.POST("/verify", controller.VerifyMFACode)
+	}
+
+	// 模板和投递记录管理，需要管理员权限
+	smsGroup := router.Group("/api/v1/sms")
+	smsGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	smsGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+	{
+		smsGroup.GET("/templates", controller.GetTemplates)
+		smsGroup.POST("/templates", controller.SaveTemplate)
+		smsGroup.DELETE("/templates/:name", controller.DeleteTemplate)
+
+		smsGroup.GET("/messages", controller.GetMessages)
+	}
+}
@@ -0,0 +1,36 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterTeamRoutes 注册组织和团队路由
+func RegisterTeamRoutes(router *gin.Engine, controller *Controllers.TeamController) {
+	authMiddleware := Middleware.NewAuthMiddleware()
+
+	// 组织路由组，需要认证
+	organizationGroup := router.Group("/api/v1/organizations")
+	organizationGroup.Use(authMiddleware.Handle())
+	{
+		organizationGroup.GET("", controller.ListOrganizations)
+		organizationGroup.POST("", controller.CreateOrganization)
+		organizationGroup.POST("/:id/teams", controller.CreateTeam)
+	}
+
+	// 团队路由组，需要认证，成员和邀请管理的权限在服务中检查
+	teamGroup := router.Group("/api/v1/teams")
+	teamGroup.Use(authMiddleware.Handle())
+	{
+		teamGroup.GET("", controller.ListTeams)
+		teamGroup.POST("/invitations/accept", controller.AcceptInvitation)
+		teamGroup.GET("/:id", controller.GetTeam)
+		teamGroup.PUT("/:id/members/:user_id", controller.UpdateMemberRole)
+		teamGroup.DELETE("/:id/members/:user_id", controller.RemoveMember)
+		teamGroup.GET("/:id/invitations", controller.ListInvitations)
+		teamGroup.POST("/:id/invitations", controller.Invite)
+		teamGroup.DELETE("/:id/invitations/:invitation_id", controller.RevokeInvitation)
+	}
+}
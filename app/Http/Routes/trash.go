@@ -0,0 +1,40 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Storage"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterTrashRoutes 注册回收站路由，所有路由需要管理员权限
+func RegisterTrashRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.TrashController) {
+	trashGroup := router.Group("/api/v1/admin/trash")
+	trashGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	trashGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+
+	typeParam := OpenAPI.Param{Name: "type", Description: "类型：users、alert-rules、dashboards、api-keys"}
+	api := OpenAPI.DefaultRegistry().Group(trashGroup, "回收站", OpenAPI.BearerAuth)
+	{
+		api.GET("/:type", OpenAPI.Route{
+			Summary:     "获取回收站中的记录",
+			Description: "按ID倒序分页列出已删除的记录",
+			Params:      []OpenAPI.Param{typeParam},
+			Errors:      []int{http.StatusNotFound},
+		}, controller.GetTrash)
+		api.POST("/:type/:id/restore", OpenAPI.Route{
+			Summary: "恢复回收站中的记录",
+			Params:  []OpenAPI.Param{typeParam, OpenAPI.PathID("id", "记录ID")},
+			Errors:  []int{http.StatusNotFound, http.StatusConflict},
+		}, controller.RestoreTrash)
+		api.DELETE("/:type/:id", OpenAPI.Route{
+			Summary:     "彻底删除回收站中的记录",
+			Description: "记录被彻底删除，无法恢复；关联数据按删除策略删除或置空，存在 restrict 关联数据时返回409",
+			Params:      []OpenAPI.Param{typeParam, OpenAPI.PathID("id", "记录ID")},
+			Errors:      []int{http.StatusNotFound, http.StatusConflict},
+		}, controller.ForceDeleteTrash)
+	}
+}
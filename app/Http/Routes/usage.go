@@ -0,0 +1,29 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterUsageRoutes 注册用量和限额路由
+func RegisterUsageRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.UsageController) {
+	authMiddleware := Middleware.NewAuthMiddleware()
+
+	// 当前用户的用量，需要认证
+	router.GET("/api/v1/usage", authMiddleware.Handle(), controller.GetMyUsage)
+
+	// 租户用量、计费导出和限额管理，需要管理员权限
+	usageGroup := router.Group("/api/v1/admin/usage")
+	usageGroup.Use(authMiddleware.Handle())
+	usageGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+	{
+		usageGroup.GET("", controller.GetTenantUsage)
+		usageGroup.GET("/records", controller.GetRecords)
+		usageGroup.GET("/quotas", controller.GetQuotas)
+		usageGroup.PUT("/quotas", controller.SetQuota)
+		usageGroup.DELETE("/quotas", controller.DeleteQuota)
+	}
+}
@@ -0,0 +1,20 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterUserSettingsRoutes 注册用户偏好设置路由
+func RegisterUserSettingsRoutes(router *gin.Engine, controller *Controllers.UserSettingsController) {
+	// 偏好设置路由组，需要认证，只能访问自己的设置
+	settingsGroup := router.Group("/api/v1/users/me/settings")
+	settingsGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	{
+		settingsGroup.GET("", controller.GetSettings)
+		settingsGroup.PATCH("", controller.UpdateSettings)
+		settingsGroup.DELETE("/:key", controller.ResetSetting)
+	}
+}
@@ -0,0 +1,107 @@
+package Routes
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterWebhookRoutes 注册Webhook订阅管理路由，所有路由需要管理员权限
+func RegisterWebhookRoutes(router *gin.Engine, storageManager *Storage.StorageManager, controller *Controllers.WebhookController) {
+	webhookGroup := router.Group("/api/v1/admin/webhooks")
+	webhookGroup.Use(Middleware.NewAuthMiddleware().Handle())
+	webhookGroup.Use(Middleware.NewPermissionMiddleware(storageManager).RequireRole("admin"))
+
+	subscriptionID := OpenAPI.PathID("id", "订阅ID")
+	deliveryID := OpenAPI.PathID("delivery_id", "投递记录ID")
+	api := OpenAPI.DefaultRegistry().Group(webhookGroup, "Webhook订阅", OpenAPI.BearerAuth)
+	{
+		api.GET("/event-types", OpenAPI.Route{
+			Summary:  "获取可订阅的事件类型",
+			Response: []Services.WebhookEventType{},
+		}, controller.GetEventTypes)
+
+		api.GET("", OpenAPI.Route{
+			Summary:  "获取Webhook订阅列表",
+			List:     &Controllers.WebhookSubscriptionQuerySpec,
+			Response: Models.WebhookSubscription{},
+		}, controller.GetSubscriptions)
+		api.POST("", OpenAPI.Route{
+			Summary:     "创建Webhook订阅",
+			Description: "secret 为空时自动生成，签名密钥只在创建和轮换时返回",
+			Request:     Services.WebhookSubscriptionInput{},
+			Response:    Controllers.WebhookSecretResponse{},
+			Status:      http.StatusCreated,
+		}, controller.CreateSubscription)
+		api.GET("/:id", OpenAPI.Route{
+			Summary:  "获取Webhook订阅详情",
+			Params:   []OpenAPI.Param{subscriptionID},
+			Response: Models.WebhookSubscription{},
+			Errors:   []int{http.StatusNotFound},
+		}, controller.GetSubscription)
+		api.PUT("/:id", OpenAPI.Route{
+			Summary:     "更新Webhook订阅",
+			Description: "secret 为空时保留原密钥",
+			Params:      []OpenAPI.Param{subscriptionID},
+			Request:     Services.WebhookSubscriptionInput{},
+			Response:    Models.WebhookSubscription{},
+			Errors:      []int{http.StatusNotFound},
+		}, controller.UpdateSubscription)
+		api.DELETE("/:id", OpenAPI.Route{
+			Summary: "删除Webhook订阅及其投递记录",
+			Params:  []OpenAPI.Param{subscriptionID},
+			Errors:  []int{http.StatusNotFound},
+		}, controller.DeleteSubscription)
+		api.POST("/:id/pause", OpenAPI.Route{
+			Summary:     "暂停Webhook订阅",
+			Description: "暂停期间的事件照常记录，恢复后补发",
+			Params:      []OpenAPI.Param{subscriptionID},
+			Response:    Models.WebhookSubscription{},
+			Errors:      []int{http.StatusNotFound},
+		}, controller.PauseSubscription)
+		api.POST("/:id/resume", OpenAPI.Route{
+			Summary:  "恢复Webhook订阅并补发积压的事件",
+			Params:   []OpenAPI.Param{subscriptionID},
+			Response: Models.WebhookSubscription{},
+			Errors:   []int{http.StatusNotFound},
+		}, controller.ResumeSubscription)
+		api.POST("/:id/rotate-secret", OpenAPI.Route{
+			Summary:  "轮换签名密钥",
+			Params:   []OpenAPI.Param{subscriptionID},
+			Response: Controllers.WebhookSecretResponse{},
+			Errors:   []int{http.StatusNotFound},
+		}, controller.RotateSecret)
+		api.POST("/:id/test", OpenAPI.Route{
+			Summary:  "发送 webhook.ping 测试事件",
+			Params:   []OpenAPI.Param{subscriptionID},
+			Response: Models.WebhookDelivery{},
+			Errors:   []int{http.StatusNotFound},
+		}, controller.TestSubscription)
+
+		api.GET("/:id/deliveries", OpenAPI.Route{
+			Summary:  "获取订阅的投递记录",
+			Params:   []OpenAPI.Param{subscriptionID},
+			List:     &Controllers.WebhookDeliveryQuerySpec,
+			Response: Models.WebhookDelivery{},
+			Errors:   []int{http.StatusNotFound},
+		}, controller.GetDeliveries)
+		api.GET("/:id/deliveries/:delivery_id", OpenAPI.Route{
+			Summary:  "获取投递记录详情",
+			Params:   []OpenAPI.Param{subscriptionID, deliveryID},
+			Response: Models.WebhookDelivery{},
+			Errors:   []int{http.StatusNotFound},
+		}, controller.GetDelivery)
+		api.POST("/:id/deliveries/:delivery_id/retry", OpenAPI.Route{
+			Summary:  "重试发送失败的投递",
+			Params:   []OpenAPI.Param{subscriptionID, deliveryID},
+			Response: Models.WebhookDelivery{},
+			Errors:   []int{http.StatusNotFound, http.StatusConflict},
+		}, controller.RetryDelivery)
+	}
+}
@@ -0,0 +1,226 @@
+package I18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Message 单条翻译消息
+//
+// 支持两种形式：
+// - 普通消息：只有 Other 字段，例如 "user.created": "用户创建成功"
+// - 复数消息：按CLDR复数类别提供多个形式，例如 "post.count": {"one": ":count post", "other": ":count posts"}
+type Message struct {
+	Zero  string `json:"zero,omitempty" yaml:"zero,omitempty"`
+	One   string `json:"one,omitempty" yaml:"one,omitempty"`
+	Two   string `json:"two,omitempty" yaml:"two,omitempty"`
+	Few   string `json:"few,omitempty" yaml:"few,omitempty"`
+	Many  string `json:"many,omitempty" yaml:"many,omitempty"`
+	Other string `json:"other,omitempty" yaml:"other,omitempty"`
+}
+
+// Form 获取指定复数类别的消息，缺失时回退到 other
+func (m Message) Form(category PluralCategory) string {
+	var text string
+	switch category {
+	case PluralZero:
+		text = m.Zero
+	case PluralOne:
+		text = m.One
+	case PluralTwo:
+		text = m.Two
+	case PluralFew:
+		text = m.Few
+	case PluralMany:
+		text = m.Many
+	}
+	if text == "" {
+		return m.Other
+	}
+	return text
+}
+
+// Catalog 单个语言的消息目录
+type Catalog struct {
+	Locale   string
+	Messages map[string]Message
+}
+
+// NewCatalog 创建空的消息目录
+func NewCatalog(locale string) *Catalog {
+	return &Catalog{
+		Locale:   locale,
+		Messages: make(map[string]Message),
+	}
+}
+
+// Lookup 查找消息
+func (c *Catalog) Lookup(key string) (Message, bool) {
+	message, ok := c.Messages[key]
+	return message, ok
+}
+
+// Merge 合并另一个目录，同名键以 other 为准
+func (c *Catalog) Merge(other *Catalog) {
+	for key, message := range other.Messages {
+		c.Messages[key] = message
+	}
+}
+
+// ParseCatalog 解析JSON或YAML格式的消息目录
+//
+// 目录支持嵌套结构，嵌套的键会以"."连接展开：
+//
+//	auth:
+//	  login_success: 登录成功
+//
+// 等价于 "auth.login_success": "登录成功"。
+// 只包含 zero/one/two/few/many/other 键的对象视为复数消息。
+func ParseCatalog(locale string, format string, data []byte) (*Catalog, error) {
+	var raw map[string]interface{}
+
+	switch strings.ToLower(format) {
+	case "json":
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("解析JSON语言包失败: %v", err)
+		}
+	case "yaml", "yml":
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("解析YAML语言包失败: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("不支持的语言包格式: %s", format)
+	}
+
+	catalog := NewCatalog(locale)
+	if err := flatten(catalog.Messages, "", raw); err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
+
+// LoadCatalogs 从文件系统加载所有语言包
+//
+// 文件名（不含扩展名）即语言代码，例如 zh-CN.json、en.yaml。
+// 同一语言的多个文件会被合并。
+func LoadCatalogs(fsys fs.FS, root string) (map[string]*Catalog, error) {
+	catalogs := make(map[string]*Catalog)
+
+	err := fs.WalkDir(fsys, root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+		if ext != "json" && ext != "yaml" && ext != "yml" {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return fmt.Errorf("读取语言包失败 %s: %v", path, err)
+		}
+
+		locale := NormalizeLocale(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+		catalog, err := ParseCatalog(locale, ext, data)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+
+		if existing, ok := catalogs[locale]; ok {
+			existing.Merge(catalog)
+		} else {
+			catalogs[locale] = catalog
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return catalogs, nil
+}
+
+// LoadCatalogDir 从本地目录加载语言包，目录不存在时返回空结果
+func LoadCatalogDir(dir string) (map[string]*Catalog, error) {
+	if dir == "" {
+		return map[string]*Catalog{}, nil
+	}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return map[string]*Catalog{}, nil
+	}
+	return LoadCatalogs(os.DirFS(dir), ".")
+}
+
+// flatten 将嵌套的消息结构展开为"."分隔的键
+func flatten(out map[string]Message, prefix string, node map[string]interface{}) error {
+	for key, value := range node {
+		fullKey := key
+		if prefix != "" {
+			fullKey = prefix + "." + key
+		}
+
+		switch v := value.(type) {
+		case string:
+			out[fullKey] = Message{Other: v}
+		case map[string]interface{}:
+			if message, ok := asPluralMessage(v); ok {
+				out[fullKey] = message
+				continue
+			}
+			if err := flatten(out, fullKey, v); err != nil {
+				return err
+			}
+		case nil:
+			continue
+		default:
+			out[fullKey] = Message{Other: fmt.Sprint(v)}
+		}
+	}
+	return nil
+}
+
+// asPluralMessage 判断对象是否为复数消息
+func asPluralMessage(node map[string]interface{}) (Message, bool) {
+	if len(node) == 0 {
+		return Message{}, false
+	}
+
+	var message Message
+	for key, value := range node {
+		text, ok := value.(string)
+		if !ok {
+			return Message{}, false
+		}
+		switch PluralCategory(key) {
+		case PluralZero:
+			message.Zero = text
+		case PluralOne:
+			message.One = text
+		case PluralTwo:
+			message.Two = text
+		case PluralFew:
+			message.Few = text
+		case PluralMany:
+			message.Many = text
+		case PluralOther:
+			message.Other = text
+		default:
+			return Message{}, false
+		}
+	}
+
+	if message.Other == "" {
+		return Message{}, false
+	}
+	return message, true
+}
@@ -0,0 +1,54 @@
+package I18n
+
+import "errors"
+
+// Error 可翻译的错误
+//
+// 服务层返回此错误时，Error() 仍输出原始（英文）描述，保持日志和已有调用方不变；
+// 控制器可以通过 Localize 按请求语言输出用户可见的消息。
+type Error struct {
+	Key     string
+	Params  Params
+	Message string
+}
+
+// NewError 创建可翻译错误，message 为默认描述
+func NewError(key, message string, params ...Params) *Error {
+	return &Error{
+		Key:     key,
+		Params:  firstParams(params),
+		Message: message,
+	}
+}
+
+// Error 实现error接口
+func (e *Error) Error() string {
+	if e.Message != "" {
+		return replaceParams(e.Message, e.Params)
+	}
+	return e.Key
+}
+
+// Localize 按指定语言输出错误消息
+func (e *Error) Localize(locale string) string {
+	translator := GetTranslator()
+	if translator.Has(locale, e.Key) {
+		return translator.Translate(locale, e.Key, e.Params)
+	}
+	return e.Error()
+}
+
+// LocalizeError 按指定语言输出任意错误的消息
+//
+// 错误链中包含 *Error 时使用其翻译键，否则返回 err.Error()。
+func LocalizeError(locale string, err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var i18nErr *Error
+	if errors.As(err, &i18nErr) {
+		return i18nErr.Localize(locale)
+	}
+	return err.Error()
+}
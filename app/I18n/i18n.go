@@ -0,0 +1,130 @@
+// Package I18n 提供API消息的国际化支持
+//
+// 重要功能说明：
+// 1. 消息目录：内置 JSON/YAML 语言包，支持外部目录覆盖和扩展
+// 2. 语言解析：根据查询参数、用户偏好、Accept-Language 解析请求语言
+// 3. 复数支持：按CLDR规则选择 one/few/many/other 等形式
+// 4. 可翻译错误：服务层返回带翻译键的错误，由控制器按请求语言输出
+//
+// 使用方式：
+// - 启动时调用 Init 加载配置中的语言包
+// - 控制器通过响应方法自动翻译消息键
+// - 服务层使用 NewError 返回可翻译的错误
+package I18n
+
+import (
+	"cloud-platform-api/app/Config"
+	"context"
+	"log"
+	"sync"
+)
+
+// contextKey 上下文键类型，避免与其他包冲突
+type contextKey string
+
+// localeContextKey 存放请求语言的上下文键
+const localeContextKey contextKey = "i18n_locale"
+
+var (
+	defaultTranslator *Translator
+	translatorOnce    sync.Once
+	translatorMu      sync.RWMutex
+)
+
+// Init 根据配置初始化全局翻译器
+//
+// 加载顺序：内置语言包 -> 配置目录中的语言包（同名键覆盖内置值）。
+// 外部目录加载失败时只记录日志，继续使用内置语言包。
+func Init(config *Config.I18nConfig) *Translator {
+	defaultLocale, fallbackLocale, path := "zh-CN", "en", ""
+	if config != nil {
+		if config.DefaultLocale != "" {
+			defaultLocale = config.DefaultLocale
+		}
+		if config.FallbackLocale != "" {
+			fallbackLocale = config.FallbackLocale
+		}
+		path = config.Path
+	}
+
+	translator, err := NewTranslatorWithBuiltin(defaultLocale, fallbackLocale)
+	if err != nil {
+		log.Printf("警告: %v", err)
+		translator = NewTranslator(defaultLocale, fallbackLocale)
+	}
+
+	if err := translator.LoadDir(path); err != nil {
+		log.Printf("警告: 加载外部语言包失败: %v", err)
+	}
+
+	SetTranslator(translator)
+	return translator
+}
+
+// SetTranslator 设置全局翻译器
+func SetTranslator(translator *Translator) {
+	translatorMu.Lock()
+	defer translatorMu.Unlock()
+	defaultTranslator = translator
+}
+
+// GetTranslator 获取全局翻译器，未初始化时使用全局配置（或默认值）懒加载
+func GetTranslator() *Translator {
+	translatorMu.RLock()
+	translator := defaultTranslator
+	translatorMu.RUnlock()
+	if translator != nil {
+		return translator
+	}
+
+	translatorOnce.Do(func() {
+		translatorMu.RLock()
+		initialized := defaultTranslator != nil
+		translatorMu.RUnlock()
+		if !initialized {
+			Init(Config.GetI18nConfig())
+		}
+	})
+
+	translatorMu.RLock()
+	defer translatorMu.RUnlock()
+	return defaultTranslator
+}
+
+// T 使用全局翻译器翻译消息
+func T(locale, key string, params ...Params) string {
+	return GetTranslator().Translate(locale, key, firstParams(params))
+}
+
+// Choice 使用全局翻译器翻译复数消息
+func Choice(locale, key string, count int64, params ...Params) string {
+	return GetTranslator().Choice(locale, key, count, firstParams(params))
+}
+
+// WithLocale 将语言写入上下文，供服务层使用
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeContextKey, locale)
+}
+
+// LocaleFromContext 从上下文读取语言，未设置时返回默认语言
+func LocaleFromContext(ctx context.Context) string {
+	if ctx != nil {
+		if locale, ok := ctx.Value(localeContextKey).(string); ok && locale != "" {
+			return locale
+		}
+	}
+	return GetTranslator().DefaultLocale()
+}
+
+// TContext 按上下文中的语言翻译消息
+func TContext(ctx context.Context, key string, params ...Params) string {
+	return T(LocaleFromContext(ctx), key, params...)
+}
+
+// firstParams 取可选参数中的第一个
+func firstParams(params []Params) Params {
+	if len(params) == 0 {
+		return nil
+	}
+	return params[0]
+}
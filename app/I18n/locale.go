@@ -0,0 +1,115 @@
+package I18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NormalizeLocale 规范化语言标签
+//
+// 例如：zh_cn -> zh-CN，EN -> en，zh-hans-cn -> zh-Hans-CN
+func NormalizeLocale(locale string) string {
+	locale = strings.TrimSpace(strings.ReplaceAll(locale, "_", "-"))
+	if locale == "" {
+		return ""
+	}
+
+	parts := strings.Split(locale, "-")
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		switch len(parts[i]) {
+		case 2:
+			parts[i] = strings.ToUpper(parts[i])
+		case 4:
+			parts[i] = strings.ToUpper(parts[i][:1]) + strings.ToLower(parts[i][1:])
+		default:
+			parts[i] = strings.ToLower(parts[i])
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// BaseLanguage 获取语言标签的主语言部分（zh-CN -> zh）
+func BaseLanguage(locale string) string {
+	locale = NormalizeLocale(locale)
+	if idx := strings.Index(locale, "-"); idx > 0 {
+		return locale[:idx]
+	}
+	return locale
+}
+
+// acceptLanguage Accept-Language中的单个语言及权重
+type acceptLanguage struct {
+	tag     string
+	quality float64
+}
+
+// ParseAcceptLanguage 解析Accept-Language请求头
+//
+// 返回按权重从高到低排序的语言列表，忽略 q=0 和通配符"*"。
+// 例如："zh-CN,zh;q=0.9,en;q=0.8" -> [zh-CN zh en]
+func ParseAcceptLanguage(header string) []string {
+	if header == "" {
+		return nil
+	}
+
+	var languages []acceptLanguage
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		tag := part
+		quality := 1.0
+		if idx := strings.Index(part, ";"); idx >= 0 {
+			tag = strings.TrimSpace(part[:idx])
+			params := strings.TrimSpace(part[idx+1:])
+			if strings.HasPrefix(params, "q=") {
+				if q, err := strconv.ParseFloat(strings.TrimPrefix(params, "q="), 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		if tag == "" || tag == "*" || quality <= 0 {
+			continue
+		}
+		languages = append(languages, acceptLanguage{tag: NormalizeLocale(tag), quality: quality})
+	}
+
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+
+	result := make([]string, 0, len(languages))
+	for _, language := range languages {
+		result = append(result, language.tag)
+	}
+	return result
+}
+
+// MatchLocale 从候选语言中选出第一个受支持的语言
+//
+// 匹配规则（按优先级）：
+// 1. 完全匹配（zh-CN == zh-CN）
+// 2. 主语言匹配（zh-TW -> zh-CN，en-US -> en）
+// 找不到时返回空字符串。
+func MatchLocale(candidates []string, supported []string) string {
+	for _, candidate := range candidates {
+		candidate = NormalizeLocale(candidate)
+		for _, locale := range supported {
+			if strings.EqualFold(candidate, locale) {
+				return locale
+			}
+		}
+		base := BaseLanguage(candidate)
+		for _, locale := range supported {
+			if BaseLanguage(locale) == base {
+				return locale
+			}
+		}
+	}
+	return ""
+}
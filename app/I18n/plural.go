@@ -0,0 +1,86 @@
+package I18n
+
+import "strings"
+
+// PluralCategory CLDR复数类别
+type PluralCategory string
+
+const (
+	PluralZero  PluralCategory = "zero"
+	PluralOne   PluralCategory = "one"
+	PluralTwo   PluralCategory = "two"
+	PluralFew   PluralCategory = "few"
+	PluralMany  PluralCategory = "many"
+	PluralOther PluralCategory = "other"
+)
+
+// PluralRule 根据数量返回复数类别
+type PluralRule func(count int64) PluralCategory
+
+// pluralRules 按语言（主标签）注册的复数规则
+//
+// 只覆盖项目实际使用的语言，未注册的语言使用英语规则。
+// 规则参考 CLDR Language Plural Rules（仅整数部分）。
+var pluralRules = map[string]PluralRule{
+	"zh": pluralRuleNone,
+	"ja": pluralRuleNone,
+	"ko": pluralRuleNone,
+	"en": pluralRuleOneOther,
+	"de": pluralRuleOneOther,
+	"es": pluralRuleOneOther,
+	"fr": pluralRuleFrench,
+	"ru": pluralRuleSlavic,
+	"uk": pluralRuleSlavic,
+}
+
+// RegisterPluralRule 注册或覆盖语言的复数规则
+func RegisterPluralRule(language string, rule PluralRule) {
+	pluralRules[strings.ToLower(language)] = rule
+}
+
+// PluralCategoryFor 获取语言在指定数量下的复数类别
+func PluralCategoryFor(locale string, count int64) PluralCategory {
+	if rule, ok := pluralRules[BaseLanguage(locale)]; ok {
+		return rule(count)
+	}
+	return pluralRuleOneOther(count)
+}
+
+// pluralRuleNone 无复数变化（中文、日文、韩文）
+func pluralRuleNone(count int64) PluralCategory {
+	return PluralOther
+}
+
+// pluralRuleOneOther 单复数两种形式（英语等）
+func pluralRuleOneOther(count int64) PluralCategory {
+	if count == 1 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+// pluralRuleFrench 法语：0和1都使用单数
+func pluralRuleFrench(count int64) PluralCategory {
+	if count == 0 || count == 1 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+// pluralRuleSlavic 俄语、乌克兰语
+func pluralRuleSlavic(count int64) PluralCategory {
+	if count < 0 {
+		count = -count
+	}
+	mod10 := count % 10
+	mod100 := count % 100
+
+	switch {
+	case mod10 == 1 && mod100 != 11:
+		return PluralOne
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return PluralFew
+	default:
+		return PluralMany
+	}
+}
@@ -0,0 +1,76 @@
+package I18n
+
+import "sync"
+
+// UserPreferenceResolver 根据用户ID获取用户偏好语言，未设置时返回空字符串
+type UserPreferenceResolver func(userID string) string
+
+var (
+	preferenceResolver   UserPreferenceResolver
+	preferenceResolverMu sync.RWMutex
+)
+
+// SetUserPreferenceResolver 设置用户偏好语言解析器
+//
+// 由应用启动时注册（通常基于用户服务），I18n 包本身不依赖数据库。
+func SetUserPreferenceResolver(resolver UserPreferenceResolver) {
+	preferenceResolverMu.Lock()
+	defer preferenceResolverMu.Unlock()
+	preferenceResolver = resolver
+}
+
+// UserPreference 获取用户偏好语言
+func UserPreference(userID string) string {
+	if userID == "" {
+		return ""
+	}
+
+	preferenceResolverMu.RLock()
+	resolver := preferenceResolver
+	preferenceResolverMu.RUnlock()
+
+	if resolver == nil {
+		return ""
+	}
+	return resolver(userID)
+}
+
+// LocaleRequest 语言解析所需的请求信息
+type LocaleRequest struct {
+	Explicit       string // 显式指定的语言（查询参数或 X-Locale 请求头）
+	UserID         string // 已认证用户ID
+	AcceptLanguage string // Accept-Language 请求头
+}
+
+// Negotiate 解析请求语言
+//
+// 优先级（从高到低）：
+// 1. 显式指定的语言
+// 2. 用户偏好语言
+// 3. Accept-Language 请求头
+// 4. 默认语言
+// 每一步都只接受受支持的语言。
+func Negotiate(request LocaleRequest, supported []string) string {
+	translator := GetTranslator()
+	if len(supported) == 0 {
+		supported = translator.Locales()
+	}
+
+	if request.Explicit != "" {
+		if locale := MatchLocale([]string{request.Explicit}, supported); locale != "" {
+			return locale
+		}
+	}
+
+	if preference := UserPreference(request.UserID); preference != "" {
+		if locale := MatchLocale([]string{preference}, supported); locale != "" {
+			return locale
+		}
+	}
+
+	if locale := MatchLocale(ParseAcceptLanguage(request.AcceptLanguage), supported); locale != "" {
+		return locale
+	}
+
+	return translator.DefaultLocale()
+}
@@ -0,0 +1,224 @@
+package I18n
+
+import (
+	"embed"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Params 翻译参数，消息中的 :name 占位符会被替换为对应的值
+type Params map[string]interface{}
+
+//go:embed lang
+var builtinLang embed.FS
+
+// Translator 翻译器
+//
+// 功能说明：
+// 1. 管理多语言消息目录（内置语言包 + 外部语言包）
+// 2. 按"当前语言 -> 回退语言 -> 键名"的顺序查找消息
+// 3. 支持 :name 命名占位符替换
+// 4. 支持基于CLDR规则的复数形式
+//
+// 使用示例：
+//
+//	t.Translate("zh-CN", "user.created", nil)
+//	t.Choice("en", "post.count", 3, nil) // "3 posts"
+//
+// 注意事项：
+// - 找不到翻译时返回键名本身，因此未迁移的原始文本可以直接传入
+// - Translator 是并发安全的，可以在运行时追加翻译
+type Translator struct {
+	mu             sync.RWMutex
+	catalogs       map[string]*Catalog
+	defaultLocale  string
+	fallbackLocale string
+}
+
+// NewTranslator 创建翻译器
+func NewTranslator(defaultLocale, fallbackLocale string) *Translator {
+	return &Translator{
+		catalogs:       make(map[string]*Catalog),
+		defaultLocale:  NormalizeLocale(defaultLocale),
+		fallbackLocale: NormalizeLocale(fallbackLocale),
+	}
+}
+
+// NewTranslatorWithBuiltin 创建加载了内置语言包的翻译器
+func NewTranslatorWithBuiltin(defaultLocale, fallbackLocale string) (*Translator, error) {
+	translator := NewTranslator(defaultLocale, fallbackLocale)
+
+	catalogs, err := LoadCatalogs(builtinLang, "lang")
+	if err != nil {
+		return nil, fmt.Errorf("加载内置语言包失败: %v", err)
+	}
+	for _, catalog := range catalogs {
+		translator.AddCatalog(catalog)
+	}
+
+	return translator, nil
+}
+
+// AddCatalog 添加消息目录，已存在的语言会合并
+func (t *Translator) AddCatalog(catalog *Catalog) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	locale := NormalizeLocale(catalog.Locale)
+	if existing, ok := t.catalogs[locale]; ok {
+		existing.Merge(catalog)
+		return
+	}
+
+	copied := NewCatalog(locale)
+	copied.Merge(catalog)
+	t.catalogs[locale] = copied
+}
+
+// AddMessage 添加单条消息
+func (t *Translator) AddMessage(locale, key, text string) {
+	catalog := NewCatalog(locale)
+	catalog.Messages[key] = Message{Other: text}
+	t.AddCatalog(catalog)
+}
+
+// LoadDir 从目录加载外部语言包，覆盖同名键
+func (t *Translator) LoadDir(dir string) error {
+	catalogs, err := LoadCatalogDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, catalog := range catalogs {
+		t.AddCatalog(catalog)
+	}
+	return nil
+}
+
+// Locales 获取已加载的语言列表
+func (t *Translator) Locales() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	locales := make([]string, 0, len(t.catalogs))
+	for locale := range t.catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// DefaultLocale 获取默认语言
+func (t *Translator) DefaultLocale() string {
+	return t.defaultLocale
+}
+
+// FallbackLocale 获取回退语言
+func (t *Translator) FallbackLocale() string {
+	return t.fallbackLocale
+}
+
+// Has 检查键在指定语言（含回退语言）中是否存在
+func (t *Translator) Has(locale, key string) bool {
+	_, _, ok := t.lookup(locale, key)
+	return ok
+}
+
+// Translate 翻译消息
+func (t *Translator) Translate(locale, key string, params Params) string {
+	message, _, ok := t.lookup(locale, key)
+	if !ok {
+		return replaceParams(key, params)
+	}
+	return replaceParams(message.Other, params)
+}
+
+// Choice 翻译复数消息
+//
+// count 会自动作为 :count 参数传入，并根据语言的复数规则选择消息形式。
+func (t *Translator) Choice(locale, key string, count int64, params Params) string {
+	message, resolvedLocale, ok := t.lookup(locale, key)
+
+	merged := Params{"count": count}
+	for name, value := range params {
+		merged[name] = value
+	}
+
+	if !ok {
+		return replaceParams(key, merged)
+	}
+
+	text := message.Other
+	if count == 0 && message.Zero != "" {
+		text = message.Zero
+	} else {
+		text = message.Form(PluralCategoryFor(resolvedLocale, count))
+	}
+	return replaceParams(text, merged)
+}
+
+// lookup 依次在指定语言、主语言、回退语言、默认语言中查找消息
+func (t *Translator) lookup(locale, key string) (Message, string, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, candidate := range t.candidates(locale) {
+		if catalog, ok := t.catalogs[candidate]; ok {
+			if message, ok := catalog.Lookup(key); ok {
+				return message, candidate, true
+			}
+		}
+	}
+	return Message{}, "", false
+}
+
+// candidates 生成语言查找顺序
+func (t *Translator) candidates(locale string) []string {
+	locale = NormalizeLocale(locale)
+	if locale == "" {
+		locale = t.defaultLocale
+	}
+
+	result := []string{locale}
+	if base := BaseLanguage(locale); base != locale {
+		result = append(result, base)
+	}
+	// 同一主语言的其他地区（zh-TW 可回退到 zh-CN）
+	base := BaseLanguage(locale)
+	siblings := make([]string, 0)
+	for candidate := range t.catalogs {
+		if candidate != locale && BaseLanguage(candidate) == base {
+			siblings = append(siblings, candidate)
+		}
+	}
+	sort.Strings(siblings)
+	result = append(result, siblings...)
+	if t.fallbackLocale != "" {
+		result = append(result, t.fallbackLocale)
+	}
+	if t.defaultLocale != "" {
+		result = append(result, t.defaultLocale)
+	}
+	return result
+}
+
+// replaceParams 替换 :name 占位符，较长的参数名优先替换，避免 :count 被 :co 截断
+func replaceParams(text string, params Params) string {
+	if len(params) == 0 || !strings.Contains(text, ":") {
+		return text
+	}
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return len(names[i]) > len(names[j])
+	})
+
+	for _, name := range names {
+		text = strings.ReplaceAll(text, ":"+name, fmt.Sprint(params[name]))
+	}
+	return text
+}
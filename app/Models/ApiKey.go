@@ -33,6 +33,7 @@ import (
 type ApiKey struct {
 	BaseModel
 	UserID      uint       `json:"user_id" gorm:"not null;index"`  // 所属用户ID
+	TeamID      *uint      `json:"team_id" gorm:"index"`           // 所属团队ID，团队成员可以管理
 	Name        string     `json:"name" gorm:"not null;size:100"`  // 密钥名称
 	KeyHash     string     `json:"-" gorm:"not null;size:255"`     // 密钥哈希（不在JSON中返回）
 	Prefix      string     `json:"prefix" gorm:"not null;size:16"` // 密钥前缀（用于识别）
@@ -0,0 +1,52 @@
+package Models
+
+import (
+	"time"
+)
+
+// 审批状态
+const (
+	ApprovalStatusPending  = "pending"  // 待审批
+	ApprovalStatusApproved = "approved" // 已通过，等待执行
+	ApprovalStatusRejected = "rejected" // 已拒绝
+	ApprovalStatusExpired  = "expired"  // 未在有效期内审批或执行
+	ApprovalStatusExecuted = "executed" // 已执行
+)
+
+// AdminApproval 敏感操作审批记录
+// 功能说明：
+// 1. 管理员发起需要审批的操作时保存请求的方法、路径和请求体摘要，执行时重新提交的请求必须一致
+// 2. 另一名管理员在 ExpiresAt 之前审批，通过后 ExpiresAt 更新为执行期限
+// 3. 执行时记录执行时间和响应状态码，每条记录只能执行一次
+type AdminApproval struct {
+	ID              uint       `json:"id" gorm:"primarykey"`
+	Action          string     `json:"action" gorm:"size:50;not null;index"` // 操作类型
+	Method          string     `json:"method" gorm:"size:10;not null"`       // 请求方法
+	Path            string     `json:"path" gorm:"size:500;not null"`        // 请求路径（含查询参数）
+	Payload         string     `json:"payload" gorm:"type:text"`             // 请求体，供审批人查看
+	PayloadHash     string     `json:"-" gorm:"size:64"`                     // 请求体的SHA-256摘要
+	Reason          string     `json:"reason" gorm:"size:500"`               // 发起原因
+	Status          string     `json:"status" gorm:"size:20;not null;index"` // 审批状态
+	RequestedBy     uint       `json:"requested_by" gorm:"not null;index"`   // 发起人
+	RequesterName   string     `json:"requester" gorm:"size:50"`             // 发起人用户名
+	IPAddress       string     `json:"ip_address" gorm:"size:45"`            // 发起请求的IP地址
+	ReviewedBy      uint       `json:"reviewed_by" gorm:"index"`             // 审批人
+	ReviewerName    string     `json:"reviewer" gorm:"size:50"`              // 审批人用户名
+	ReviewComment   string     `json:"review_comment" gorm:"size:500"`       // 审批意见
+	ReviewedAt      *time.Time `json:"reviewed_at"`                          // 审批时间
+	ExpiresAt       time.Time  `json:"expires_at" gorm:"index"`              // 审批期限，通过后为执行期限
+	ExecutedAt      *time.Time `json:"executed_at"`                          // 执行时间
+	ExecutionStatus int        `json:"execution_status"`                     // 执行请求的响应状态码
+	CreatedAt       time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (AdminApproval) TableName() string {
+	return "admin_approvals"
+}
+
+// IsExpired 审批或执行期限是否已过
+func (a *AdminApproval) IsExpired(now time.Time) bool {
+	return (a.Status == ApprovalStatusPending || a.Status == ApprovalStatusApproved) && !now.Before(a.ExpiresAt)
+}
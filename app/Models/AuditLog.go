@@ -1,8 +1,11 @@
 package Models
 
 import (
+	"cloud-platform-api/app/Utils"
 	"encoding/json"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // AuditLog 审计日志模型
@@ -24,11 +27,13 @@ type AuditLog struct {
 	IPAddress   string      `json:"ip_address" gorm:"size:45"`              // IP地址
 	UserAgent   string      `json:"user_agent" gorm:"size:500"`             // 用户代理
 	RequestID   string      `json:"request_id" gorm:"size:100"`             // 请求ID
+	CorrelationID string    `json:"correlation_id" gorm:"size:100;index"`   // 关联ID，跨系统关联同一组请求
 	Status      string      `json:"status" gorm:"size:20;default:'success'"` // 操作状态：success, failed
 	ErrorMsg    string      `json:"error_msg" gorm:"size:500"`              // 错误信息
 	BeforeData  string      `json:"before_data" gorm:"type:text"`           // 操作前数据（JSON格式）
 	AfterData   string      `json:"after_data" gorm:"type:text"`            // 操作后数据（JSON格式）
 	Metadata    string      `json:"metadata" gorm:"type:text"`              // 额外元数据（JSON格式）
+	ImpersonatorID uint     `json:"impersonator_id,omitempty" gorm:"index"`  // 模拟登录的管理员ID，非模拟请求为0
 	CreatedAt   time.Time   `json:"created_at" gorm:"index"`                // 创建时间
 	
 	// 关联关系
@@ -80,6 +85,21 @@ const (
 	AuditActionSystemBackup     = "system.backup"
 	AuditActionSystemRestore    = "system.restore"
 	AuditActionSystemMaintenance = "system.maintenance"
+
+	// 管理员模拟登录
+	AuditActionImpersonationStart   = "impersonation.start"
+	AuditActionImpersonationEnd     = "impersonation.end"
+	AuditActionImpersonationRequest = "impersonation.request"
+
+	// 敏感操作审批
+	AuditActionApprovalRequest = "approval.request"
+	AuditActionApprovalApprove = "approval.approve"
+	AuditActionApprovalReject  = "approval.reject"
+	AuditActionApprovalExecute = "approval.execute"
+
+	// 评论
+	AuditActionCommentUpdate = "comment.update"
+	AuditActionCommentDelete = "comment.delete"
 )
 
 // AuditStatus 审计状态
@@ -132,6 +152,29 @@ func (a *AuditLog) SetRequestID(requestID string) *AuditLog {
 	return a
 }
 
+// SetCorrelationID 设置关联ID
+func (a *AuditLog) SetCorrelationID(correlationID string) *AuditLog {
+	a.CorrelationID = correlationID
+	return a
+}
+
+// BeforeCreate 未设置请求ID和关联ID时从数据库操作的上下文（db.WithContext）中获取
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	if a.RequestID == "" {
+		a.RequestID = Utils.RequestIDFromContext(tx.Statement.Context)
+	}
+	if a.CorrelationID == "" {
+		a.CorrelationID = Utils.CorrelationIDFromContext(tx.Statement.Context)
+	}
+	return nil
+}
+
+// SetImpersonator 标记为管理员模拟登录期间的操作
+func (a *AuditLog) SetImpersonator(impersonatorID uint) *AuditLog {
+	a.ImpersonatorID = impersonatorID
+	return a
+}
+
 // SetError 设置错误信息
 func (a *AuditLog) SetError(errorMsg string) *AuditLog {
 	a.ErrorMsg = errorMsg
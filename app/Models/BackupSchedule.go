@@ -0,0 +1,59 @@
+package Models
+
+import "time"
+
+// BackupSchedule 定时备份
+// 按cron表达式创建备份，备份保存在备份目录，可另外上传到归档存储；每个计划的备份按保留策略单独清理
+type BackupSchedule struct {
+	ID                  uint       `gorm:"primaryKey" json:"id"`
+	Name                string     `gorm:"size:100;not null" json:"name"`                   // 名称
+	Type                string     `gorm:"size:20;not null" json:"type"`                    // 备份类型：database、files、full、tables
+	Tables              string     `gorm:"size:1000" json:"tables"`                         // 按表备份的表，逗号分隔
+	Domains             string     `gorm:"size:200" json:"domains"`                         // 按表备份的领域，逗号分隔
+	Schedule            string     `gorm:"size:100;not null" json:"schedule"`               // cron表达式
+	Target              string     `gorm:"size:20;not null;default:'local'" json:"target"`  // 存储位置：local 只保存在备份目录，archive 另外上传到归档存储
+	RetentionCount      int        `gorm:"not null;default:0" json:"retention_count"`       // 保留最近的备份数，0表示不限制
+	RetentionDays       int        `gorm:"not null;default:0" json:"retention_days"`        // 备份保留天数，0表示不限制
+	NotifyOnSuccess     bool       `gorm:"not null;default:false" json:"notify_on_success"` // 成功时也通知管理员，失败和失败后恢复总是通知
+	Enabled             bool       `gorm:"not null;default:true" json:"enabled"`            // 是否启用
+	NextRunAt           *time.Time `gorm:"index" json:"next_run_at"`                        // 下次运行时间
+	LastRunAt           *time.Time `json:"last_run_at"`                                     // 上次运行时间
+	LastStatus          string     `gorm:"size:20" json:"last_status"`                      // 上次运行结果：success、failed
+	LastError           string     `gorm:"size:1000" json:"last_error"`                     // 上次失败原因
+	LastBackupID        string     `gorm:"size:100" json:"last_backup_id"`                  // 上次成功创建的备份
+	ConsecutiveFailures int        `gorm:"not null;default:0" json:"consecutive_failures"`  // 连续失败次数
+	RunCount            int        `gorm:"not null;default:0" json:"run_count"`             // 运行次数，多实例部署时用于抢占
+	CreatedBy           uint       `gorm:"not null" json:"created_by"`                      // 创建者ID
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// 备份运行状态
+const (
+	BackupRunPending = "pending"
+	BackupRunRunning = "running"
+	BackupRunSuccess = "success"
+	BackupRunFailed  = "failed"
+)
+
+// BackupRun 备份运行记录
+// 定时运行和手动触发各生成一条记录，运行期间更新阶段和进度，供接口轮询
+type BackupRun struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	ScheduleID  uint       `gorm:"index;not null" json:"schedule_id"`     // 定时备份ID
+	Trigger     string     `gorm:"size:20;not null" json:"trigger"`       // 触发方式：schedule、manual
+	TriggeredBy uint       `json:"triggered_by,omitempty"`                // 手动触发的用户ID
+	Status      string     `gorm:"size:20;not null;index" json:"status"`  // pending、running、success、failed
+	Stage       string     `gorm:"size:30" json:"stage"`                  // 当前阶段：queued、backup、upload、retention、done
+	Progress    int        `gorm:"not null;default:0" json:"progress"`    // 进度百分比
+	BackupID    string     `gorm:"size:100;index" json:"backup_id"`       // 创建的备份
+	BackupPath  string     `gorm:"size:500" json:"backup_path"`           // 备份文件路径，保留策略删除后清空
+	Size        int64      `gorm:"not null;default:0" json:"size"`        // 备份文件大小
+	Target      string     `gorm:"size:20" json:"target"`                 // 存储位置
+	ObjectKey   string     `gorm:"size:500" json:"object_key,omitempty"`  // 归档存储中的对象键
+	Error       string     `gorm:"size:1000" json:"error,omitempty"`      // 失败原因
+	StartedAt   *time.Time `json:"started_at"`                            // 开始时间
+	FinishedAt  *time.Time `json:"finished_at"`                           // 结束时间
+	DurationMS  int64      `gorm:"not null;default:0" json:"duration_ms"` // 耗时（毫秒）
+	CreatedAt   time.Time  `gorm:"index" json:"created_at"`
+}
@@ -0,0 +1,55 @@
+package Models
+
+import "time"
+
+// Stripe 订阅状态中仍可使用套餐的状态
+const (
+	BillingStatusActive   = "active"
+	BillingStatusTrialing = "trialing"
+	BillingStatusPastDue  = "past_due" // 扣款失败，Stripe 重试期间保留套餐
+	BillingStatusCanceled = "canceled"
+)
+
+// BillingPlan 套餐
+// 功能说明：
+// 1. 每个套餐对应一个 Stripe 价格，用户订阅后按套餐的限额统计用量
+// 2. 限额为0表示不限制，Features 为套餐包含的高级功能，逗号分隔
+type BillingPlan struct {
+	ID                     uint      `gorm:"primaryKey" json:"id"`
+	Code                   string    `gorm:"size:50;not null;uniqueIndex" json:"code"`             // 套餐代码，如 pro
+	Name                   string    `gorm:"size:100;not null" json:"name"`                        // 套餐名称
+	StripePriceID          string    `gorm:"size:100;not null;uniqueIndex" json:"stripe_price_id"` // Stripe 价格ID
+	QuotaAPICalls          int64     `gorm:"not null;default:0" json:"quota_api_calls"`            // 每周期API调用次数限额
+	QuotaStorageBytes      int64     `gorm:"not null;default:0" json:"quota_storage_bytes"`        // 每周期上传字节数限额
+	QuotaMetricsIngested   int64     `gorm:"not null;default:0" json:"quota_metrics_ingested"`     // 每周期推送指标采样数限额
+	QuotaNotificationsSent int64     `gorm:"not null;default:0" json:"quota_notifications_sent"`   // 每周期邮件和短信通知数限额
+	Features               string    `gorm:"size:500" json:"features"`                             // 高级功能，逗号分隔
+	Active                 bool      `gorm:"not null;default:true" json:"active"`                  // 是否可以订阅，已订阅的用户不受影响
+	CreatedAt              time.Time `json:"created_at"`
+	UpdatedAt              time.Time `json:"updated_at"`
+}
+
+// BillingAccount 用户的 Stripe 客户和订阅
+// 订阅信息由 Stripe Webhook 同步，StripeEventAt 用于忽略乱序到达的旧事件
+type BillingAccount struct {
+	ID                   uint       `gorm:"primaryKey" json:"id"`
+	UserID               uint       `gorm:"not null;uniqueIndex" json:"user_id"`                     // 用户ID
+	StripeCustomerID     string     `gorm:"size:100;not null;uniqueIndex" json:"stripe_customer_id"` // Stripe 客户ID
+	StripeSubscriptionID string     `gorm:"size:100;index" json:"stripe_subscription_id"`            // Stripe 订阅ID
+	StripeItemID         string     `gorm:"size:100" json:"-"`                                       // 订阅项ID，更换价格时使用
+	PlanCode             string     `gorm:"size:50;index" json:"plan_code"`                          // 当前套餐代码
+	Status               string     `gorm:"size:30" json:"status"`                                   // Stripe 订阅状态
+	CurrentPeriodEnd     *time.Time `json:"current_period_end"`                                      // 当前计费周期结束时间
+	CancelAtPeriodEnd    bool       `gorm:"not null;default:false" json:"cancel_at_period_end"`      // 是否在周期结束时取消
+	StripeEventAt        *time.Time `json:"-"`                                                       // 最近同步的订阅事件时间
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+}
+
+// BillingEvent 已处理的 Stripe Webhook 事件，Stripe 重复投递时跳过
+type BillingEvent struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	StripeEventID string    `gorm:"size:100;not null;uniqueIndex" json:"stripe_event_id"` // Stripe 事件ID
+	Type          string    `gorm:"size:100;not null" json:"type"`                        // 事件类型
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -0,0 +1,79 @@
+package Models
+
+import (
+	"time"
+)
+
+// 批量任务类型
+const (
+	BulkJobUserImport = "user_import" // 导入用户
+	BulkJobRoleAssign = "role_assign" // 批量分配角色
+	BulkJobUserStatus = "user_status" // 批量启用/禁用用户
+	BulkJobUserDelete = "user_delete" // 批量删除用户
+)
+
+// 批量任务状态
+const (
+	BulkJobQueued    = "queued"    // 已入队等待执行
+	BulkJobRunning   = "running"   // 正在执行
+	BulkJobCompleted = "completed" // 执行完成（部分行失败也视为完成，见错误明细）
+	BulkJobFailed    = "failed"    // 任务整体失败
+)
+
+// BulkJob 批量任务
+// 功能说明：
+// 1. 每次批量操作一条记录，由后台协程异步执行，通过 Processed / Total 查询进度
+// 2. Payload 保存待处理的行（JSON），导入任务完成后清空，避免明文密码留在数据库中
+// 3. DryRun 为 true 时只校验不写入，Succeeded 表示可以成功导入的行数
+type BulkJob struct {
+	ID         uint       `json:"id" gorm:"primarykey"`
+	Type       string     `json:"type" gorm:"size:30;not null;index"`   // 任务类型
+	Status     string     `json:"status" gorm:"size:20;not null;index"` // 任务状态
+	DryRun     bool       `json:"dry_run"`                              // 是否只校验
+	Payload    string     `json:"-" gorm:"size:16777215"`               // 待处理的行(JSON)，较大的size使MySQL使用mediumtext
+	Total      int        `json:"total"`                                // 总行数
+	Processed  int        `json:"processed"`                            // 已处理行数
+	Succeeded  int        `json:"succeeded"`                            // 成功行数
+	Failed     int        `json:"failed"`                               // 失败行数
+	LastError  string     `json:"last_error" gorm:"type:text"`          // 任务整体失败的原因
+	CreatedBy  uint       `json:"created_by" gorm:"index"`              // 提交任务的管理员
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (BulkJob) TableName() string {
+	return "bulk_jobs"
+}
+
+// Progress 返回任务进度百分比
+func (j *BulkJob) Progress() float64 {
+	if j.Total == 0 {
+		if j.Status == BulkJobCompleted {
+			return 100
+		}
+		return 0
+	}
+	return float64(j.Processed) * 100 / float64(j.Total)
+}
+
+// BulkJobError 批量任务的行级错误
+// 功能说明：
+// 1. 每个校验失败或执行失败的字段一条记录，同一行可能有多条
+// 2. Row 为导入文件中的行号（CSV含表头行）或用户ID所在的序号，Identifier 为用户名、邮箱或用户ID
+type BulkJobError struct {
+	ID         uint      `json:"id" gorm:"primarykey"`
+	JobID      uint      `json:"job_id" gorm:"not null;index"` // 批量任务ID
+	Row        int       `json:"row" gorm:"column:row_no"`     // 行号（row 是MySQL保留字）
+	Identifier string    `json:"identifier" gorm:"size:255"`   // 用户名、邮箱或用户ID
+	Field      string    `json:"field" gorm:"size:50"`         // 出错的字段
+	Message    string    `json:"message" gorm:"size:500"`      // 错误信息
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (BulkJobError) TableName() string {
+	return "bulk_job_errors"
+}
@@ -0,0 +1,32 @@
+package Models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 可以评论的资源类型
+const (
+	CommentResourceAlert         = "alert"          // 监控告警，资源ID为告警ID
+	CommentResourceSecurityEvent = "security_event" // 安全事件，资源ID为事件ID
+	CommentResourceReport        = "report"         // 监控报告，资源ID为报告ID
+)
+
+// Comment 告警、安全事件和报告的评论
+// 回复只挂在顶层评论下（两级线程），删除为软删除，有回复的已删除评论在线程中保留占位
+type Comment struct {
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	ResourceType string         `gorm:"size:30;not null;index:idx_comments_resource" json:"resource_type"` // 资源类型：alert、security_event、report
+	ResourceID   string         `gorm:"size:100;not null;index:idx_comments_resource" json:"resource_id"`  // 资源ID
+	ParentID     *uint          `gorm:"index" json:"parent_id,omitempty"`                                  // 回复的顶层评论ID
+	UserID       uint           `gorm:"not null;index" json:"user_id"`                                     // 作者ID
+	Username     string         `gorm:"size:50;not null" json:"username"`                                  // 作者用户名
+	Content      string         `gorm:"type:text;not null" json:"content"`                                 // 评论内容
+	Mentions     string         `gorm:"size:1000" json:"mentions"`                                         // @提及的用户名，逗号分隔
+	EditedAt     *time.Time     `json:"edited_at,omitempty"`                                               // 最后编辑时间
+	DeletedBy    *uint          `json:"deleted_by,omitempty"`                                              // 删除者ID
+	CreatedAt    time.Time      `gorm:"index" json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"` // 删除时间
+}
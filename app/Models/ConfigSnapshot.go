@@ -0,0 +1,20 @@
+package Models
+
+import "time"
+
+// ConfigSnapshot 配置快照
+// 保存某一时刻脱敏后的有效配置，用于比较部署之间和环境之间的配置差异
+type ConfigSnapshot struct {
+	ID          uint                   `gorm:"primaryKey" json:"id"`
+	Environment string                 `gorm:"size:50;not null;index:idx_config_snapshot_env_version" json:"environment"` // 环境，如 production、staging
+	Version     int                    `gorm:"not null;index:idx_config_snapshot_env_version" json:"version"`             // 环境内的版本号，从1递增
+	Host        string                 `gorm:"size:255" json:"host"`                                                      // 生成快照的主机
+	Label       string                 `gorm:"size:200" json:"label"`                                                     // 说明，如部署版本号
+	Source      string                 `gorm:"size:20;not null" json:"source"`                                            // 来源：capture 当前实例生成，startup 启动时生成，import 从其他环境导入
+	Checksum    string                 `gorm:"size:64;not null;index" json:"checksum"`                                    // 配置内容的SHA-256
+	KeyCount    int                    `gorm:"not null;default:0" json:"key_count"`                                       // 配置项数量
+	Data        string                 `gorm:"type:text;not null" json:"-"`                                               // 脱敏后的配置，JSON对象
+	Values      map[string]interface{} `gorm:"-" json:"values,omitempty"`                                                 // 查看单个快照时返回的配置
+	CreatedBy   uint                   `json:"created_by"`                                                                // 创建者ID，启动时生成的快照为0
+	CreatedAt   time.Time              `gorm:"index" json:"created_at"`
+}
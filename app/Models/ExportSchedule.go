@@ -0,0 +1,26 @@
+package Models
+
+import "time"
+
+// ExportSchedule 定时导出
+// 按cron表达式导出告警、登录尝试或安全事件，结果作为邮件附件发送给接收者
+type ExportSchedule struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Name       string     `gorm:"size:100;not null" json:"name"`                // 名称
+	Dataset    string     `gorm:"size:30;not null" json:"dataset"`              // 数据集：alerts、login_attempts、security_events
+	Format     string     `gorm:"size:10;not null;default:'csv'" json:"format"` // 格式：csv、xlsx
+	Query      string     `gorm:"size:2000" json:"query"`                       // 筛选条件，与列表接口相同的查询字符串，如 filter[event_level][eq]=high
+	Since      string     `gorm:"size:20" json:"since"`                         // 只导出最近一段时间的数据，如 24h，为空时不限制
+	Schedule   string     `gorm:"size:100;not null" json:"schedule"`            // cron表达式
+	Recipients string     `gorm:"size:1000;not null" json:"recipients"`         // 接收邮箱，逗号分隔
+	Enabled    bool       `gorm:"not null;default:true" json:"enabled"`         // 是否启用
+	NextRunAt  *time.Time `gorm:"index" json:"next_run_at"`                     // 下次运行时间
+	LastRunAt  *time.Time `json:"last_run_at"`                                  // 上次运行时间
+	LastStatus string     `gorm:"size:20" json:"last_status"`                   // 上次运行结果：success、failed
+	LastError  string     `gorm:"size:1000" json:"last_error"`                  // 上次失败原因
+	LastRows   int        `gorm:"not null;default:0" json:"last_rows"`          // 上次导出的行数
+	RunCount   int        `gorm:"not null;default:0" json:"run_count"`          // 运行次数，多实例部署时用于抢占
+	CreatedBy  uint       `gorm:"not null" json:"created_by"`                   // 创建者ID
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
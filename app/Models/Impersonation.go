@@ -0,0 +1,39 @@
+package Models
+
+import (
+	"time"
+)
+
+// ImpersonationSession 管理员模拟登录会话
+// 功能说明：
+// 1. 管理员每次模拟登录创建一条会话，模拟令牌通过 SessionID 关联会话
+// 2. 会话过期或被结束（EndedAt 不为空）后模拟令牌立即失效
+// 3. ReadOnly 为 true 时模拟期间只允许查询，RequestCount 记录模拟期间的请求数
+type ImpersonationSession struct {
+	ID               uint       `json:"id" gorm:"primarykey"`
+	SessionID        string     `json:"session_id" gorm:"size:36;not null;uniqueIndex"` // 会话ID，写入模拟令牌
+	ImpersonatorID   uint       `json:"impersonator_id" gorm:"not null;index"`          // 发起模拟的管理员
+	ImpersonatorName string     `json:"impersonator" gorm:"size:50"`                    // 管理员用户名
+	TargetUserID     uint       `json:"target_user_id" gorm:"not null;index"`           // 被模拟的用户
+	TargetUsername   string     `json:"target_username" gorm:"size:50"`                 // 被模拟的用户名
+	Reason           string     `json:"reason" gorm:"size:500"`                         // 模拟原因
+	ReadOnly         bool       `json:"read_only"`                                      // 是否只读
+	IPAddress        string     `json:"ip_address" gorm:"size:45"`                      // 发起模拟的IP地址
+	RequestCount     int64      `json:"request_count"`                                  // 模拟期间的请求数
+	LastRequestAt    *time.Time `json:"last_request_at"`                                // 最后一次请求时间
+	ExpiresAt        time.Time  `json:"expires_at" gorm:"index"`                        // 过期时间
+	EndedAt          *time.Time `json:"ended_at"`                                       // 结束时间，未结束为空
+	EndedBy          uint       `json:"ended_by"`                                       // 结束会话的管理员
+	CreatedAt        time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (ImpersonationSession) TableName() string {
+	return "impersonation_sessions"
+}
+
+// IsActive 会话是否仍然有效
+func (s *ImpersonationSession) IsActive(now time.Time) bool {
+	return s.EndedAt == nil && now.Before(s.ExpiresAt)
+}
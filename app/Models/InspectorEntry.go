@@ -0,0 +1,42 @@
+package Models
+
+import (
+	"time"
+)
+
+// InspectorEntry 请求检查器记录模型
+// 功能说明：
+// 1. 一条记录对应一个被采样的请求，保存请求头、请求体、响应头、响应体和异常信息
+// 2. 请求头和请求体已按日志脱敏规则处理，请求体和响应体超过 INSPECTOR_MAX_BODY_SIZE 时截断
+// 3. Details 保存请求执行的SQL、缓存操作、出站HTTP调用和发送的通知（JSON），只在详情接口返回
+//
+// 耗时单位均为毫秒。
+type InspectorEntry struct {
+	ID                uint      `json:"id" gorm:"primarykey"`
+	RequestID         string    `json:"request_id" gorm:"size:64;index"`
+	Method            string    `json:"method" gorm:"size:10"`
+	Path              string    `json:"path" gorm:"size:500;index"`
+	Route             string    `json:"route" gorm:"size:500"` // 匹配的路由模板，未匹配时为空
+	StatusCode        int       `json:"status_code" gorm:"index"`
+	DurationMs        float64   `json:"duration_ms"`
+	UserID            *uint     `json:"user_id" gorm:"index"`
+	ClientIP          string    `json:"client_ip" gorm:"size:45"`
+	RequestHeaders    string    `json:"-" gorm:"type:text"`
+	RequestBody       string    `json:"-" gorm:"type:text"`
+	ResponseHeaders   string    `json:"-" gorm:"type:text"`
+	ResponseBody      string    `json:"-" gorm:"type:text"`
+	Exception         string    `json:"exception,omitempty" gorm:"type:text"` // panic 信息和堆栈，或处理器记录的错误
+	HasException      bool      `json:"has_exception" gorm:"index"`
+	QueryCount        int       `json:"query_count"`
+	QueryMs           float64   `json:"query_ms"`
+	CacheCount        int       `json:"cache_count"`
+	HTTPCount         int       `json:"http_count"`
+	NotificationCount int       `json:"notification_count"`
+	Details           string    `json:"-" gorm:"type:text"`
+	CreatedAt         time.Time `json:"created_at" gorm:"index"`
+}
+
+// TableName 指定表名
+func (InspectorEntry) TableName() string {
+	return "inspector_entries"
+}
@@ -0,0 +1,70 @@
+package Models
+
+import (
+	"time"
+)
+
+// 邮件投递状态
+const (
+	MailStatusQueued     = "queued"     // 已入队等待发送
+	MailStatusSending    = "sending"    // 正在发送
+	MailStatusSent       = "sent"       // 服务商已接收
+	MailStatusRetrying   = "retrying"   // 临时失败，等待重试
+	MailStatusFailed     = "failed"     // 永久失败或重试次数用尽
+	MailStatusSuppressed = "suppressed" // 收件人全部在屏蔽列表中，未发送
+	MailStatusBounced    = "bounced"    // 发送后收到退信
+)
+
+// MailMessage 邮件投递记录
+// 功能说明：
+// 1. 每封邮件一条记录，保存收件人、主题、使用的驱动和投递状态
+// 2. Payload 保存完整邮件内容（含附件），用于异步发送、重试和服务重启后恢复队列
+// 3. 服务商返回的消息ID用于关联退信回调
+type MailMessage struct {
+	ID                uint       `json:"id" gorm:"primarykey"`
+	MessageID         string     `json:"message_id" gorm:"size:64;uniqueIndex;not null"` // 内部消息ID
+	Driver            string     `json:"driver" gorm:"size:20"`                          // 发送驱动
+	Recipients        string     `json:"recipients" gorm:"type:text"`                    // 收件人，逗号分隔
+	Subject           string     `json:"subject" gorm:"size:255"`                        // 主题
+	Template          string     `json:"template" gorm:"size:100"`                       // 使用的模板
+	Payload           string     `json:"-" gorm:"size:16777215"`                         // 邮件内容(JSON)，较大的size使MySQL使用mediumtext
+	Status            string     `json:"status" gorm:"size:20;not null;index"`           // 投递状态
+	Attempts          int        `json:"attempts"`                                       // 已尝试次数
+	LastError         string     `json:"last_error" gorm:"type:text"`                    // 最近一次错误
+	ProviderMessageID string     `json:"provider_message_id" gorm:"size:255;index"`      // 服务商消息ID
+	NextAttemptAt     *time.Time `json:"next_attempt_at" gorm:"index"`                   // 下次重试时间
+	SentAt            *time.Time `json:"sent_at"`                                        // 发送成功时间
+	CreatedAt         time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (MailMessage) TableName() string {
+	return "mail_messages"
+}
+
+// 邮件屏蔽原因
+const (
+	MailSuppressionBounce    = "bounce"    // 硬退信
+	MailSuppressionComplaint = "complaint" // 垃圾邮件投诉
+	MailSuppressionManual    = "manual"    // 管理员手动添加
+)
+
+// MailSuppression 邮件屏蔽列表
+// 功能说明：
+// 1. 硬退信、投诉的地址加入屏蔽列表，之后发往这些地址的邮件直接跳过
+// 2. 邮箱地址统一转为小写保存
+type MailSuppression struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	Email     string    `json:"email" gorm:"size:255;uniqueIndex;not null"` // 邮箱地址
+	Reason    string    `json:"reason" gorm:"size:20;not null"`             // 屏蔽原因
+	Source    string    `json:"source" gorm:"size:50"`                      // 来源（驱动名或manual）
+	Detail    string    `json:"detail" gorm:"type:text"`                    // 退信详情
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (MailSuppression) TableName() string {
+	return "mail_suppressions"
+}
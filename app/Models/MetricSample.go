@@ -0,0 +1,22 @@
+package Models
+
+import (
+	"time"
+)
+
+// MetricSample 指标历史采样
+// 功能说明：
+// 1. 监控服务批量刷新指标时写入数值型指标，用于动态阈值计算和告警规则回测
+// 2. 按指标名称和时间联合索引，按时间范围查询单个指标
+// 3. 超过监控存储保留期（MONITORING_STORAGE_DATABASE_RETENTION）的采样定期清理
+type MetricSample struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	Name      string    `json:"name" gorm:"size:100;not null;index:idx_metric_samples_name_time,priority:1"` // 指标名称
+	Value     float64   `json:"value" gorm:"not null"`                                                       // 指标值
+	Timestamp time.Time `json:"timestamp" gorm:"not null;index:idx_metric_samples_name_time,priority:2"`     // 采样时间
+}
+
+// TableName 指定表名
+func (MetricSample) TableName() string {
+	return "metric_samples"
+}
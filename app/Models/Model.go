@@ -24,11 +24,13 @@ type Model interface {
 // 2. 包含主键ID、创建时间、更新时间、删除时间
 // 3. 支持GORM的软删除功能
 // 4. 自动管理时间戳字段
+// 5. 版本号用于乐观锁，通过 SaveWithVersion 更新时递增
 type BaseModel struct {
 	ID        uint           `json:"id" gorm:"primarykey"`              // 主键ID
 	CreatedAt time.Time      `json:"created_at"`                        // 创建时间
 	UpdatedAt time.Time      `json:"updated_at"`                        // 更新时间
 	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"` // 删除时间（软删除）
+	Version   uint           `json:"version" gorm:"not null;default:1"` // 版本号（乐观锁）
 }
 
 // GetID 获取ID
@@ -63,6 +65,16 @@ func (m *BaseModel) GetDeletedAt() gorm.DeletedAt {
 	return m.DeletedAt
 }
 
+// GetVersion 获取版本号
+func (m *BaseModel) GetVersion() uint {
+	return m.Version
+}
+
+// SetVersion 设置版本号
+func (m *BaseModel) SetVersion(version uint) {
+	m.Version = version
+}
+
 // IsDeleted 检查是否已删除
 //
 // 功能说明：
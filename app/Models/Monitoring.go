@@ -2,6 +2,8 @@ package Models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // MonitoringMetric 监控指标
@@ -22,6 +24,12 @@ type MonitoringMetric struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// 告警规则可见范围
+const (
+	AlertRuleVisibilityPublic = "public" // 所有可以查看规则指标的用户可见
+	AlertRuleVisibilityTeam   = "team"   // 只对管理员、创建者和所属团队的成员可见
+)
+
 // AlertRule 告警规则
 type AlertRule struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
@@ -43,8 +51,12 @@ type AlertRule struct {
 	NotificationChannels string `gorm:"size:500" json:"notification_channels"` // 通知渠道（JSON格式）
 	Tags        string    `gorm:"size:1000" json:"tags"`                         // 标签（JSON格式）
 	CreatedBy   uint      `gorm:"not null" json:"created_by"`                    // 创建者ID
+	TeamID      *uint     `gorm:"index" json:"team_id"`                          // 所属团队ID，团队成员可以修改
+	Visibility  string    `gorm:"size:20;not null;default:'public'" json:"visibility"` // 可见范围：public, team
+	Version     uint      `gorm:"not null;default:1" json:"version"`             // 版本号（乐观锁）
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`      // 删除时间（回收站）
 }
 
 // Alert 告警记录
@@ -63,7 +75,10 @@ type Alert struct {
 	Description string    `gorm:"size:1000" json:"description"`                 // 详细描述
 	Tags        string    `gorm:"size:1000" json:"tags"`                        // 标签（JSON格式）
 	Metadata    string    `gorm:"type:text" json:"metadata"`                    // 元数据（JSON格式）
+	Fingerprint string    `gorm:"size:64;index" json:"fingerprint"`             // 告警指纹（规则+指标+标签）
+	Count       int       `gorm:"not null;default:1" json:"count"`             // 未解决期间的触发次数
 	FiredAt     time.Time `gorm:"not null;index" json:"fired_at"`              // 触发时间
+	LastSeenAt  time.Time `json:"last_seen_at"`                                // 最近一次触发时间
 	AcknowledgedAt *time.Time `json:"acknowledged_at"`                         // 确认时间
 	AcknowledgedBy *uint     `json:"acknowledged_by"`                          // 确认者ID
 	ResolvedAt  *time.Time `json:"resolved_at"`                                // 解决时间
@@ -97,18 +112,23 @@ type NotificationRecord struct {
 }
 
 // MonitoringDashboard 监控仪表板
+// 创建者、管理员和所属团队的成员可以修改；公开的仪表板所有登录用户可见，否则只对 SharedRoles 中的角色和所属团队的成员可见
 type MonitoringDashboard struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
 	Name        string    `gorm:"size:100;not null;uniqueIndex" json:"name"`   // 仪表板名称
 	Description string    `gorm:"size:500" json:"description"`                 // 描述
 	Layout      string    `gorm:"type:text;not null" json:"layout"`          // 布局配置（JSON格式）
-	Widgets     string    `gorm:"type:text;not null" json:"widgets"`         // 组件配置（JSON格式）
 	RefreshInterval int   `gorm:"not null;default:30" json:"refresh_interval"` // 刷新间隔（秒）
 	IsDefault   bool      `gorm:"not null;default:false" json:"is_default"`   // 是否默认仪表板
 	IsPublic   bool      `gorm:"not null;default:false" json:"is_public"`     // 是否公开
+	SharedRoles string    `gorm:"size:255" json:"shared_roles"`               // 共享的角色，逗号分隔
 	CreatedBy   uint      `gorm:"not null" json:"created_by"`                 // 创建者ID
+	TeamID      *uint     `gorm:"index" json:"team_id"`                       // 所属团队ID
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`     // 删除时间（回收站），组件保留到彻底删除
+
+	Widgets []MonitoringWidget `gorm:"foreignKey:DashboardID" json:"widgets,omitempty"` // 组件
 }
 
 // MonitoringWidget 监控组件
@@ -116,12 +136,13 @@ type MonitoringWidget struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
 	DashboardID uint      `gorm:"not null;index" json:"dashboard_id"`         // 仪表板ID
 	Name        string    `gorm:"size:100;not null" json:"name"`              // 组件名称
-	Type        string    `gorm:"size:50;not null" json:"type"`               // 组件类型：chart, gauge, table, text, alert
+	Type        string    `gorm:"size:50;not null" json:"type"`               // 组件类型：line, area, bar, gauge, stat, table, text, alert
 	Config      string    `gorm:"type:text;not null" json:"config"`         // 配置（JSON格式）
 	Position    string    `gorm:"size:100;not null" json:"position"`        // 位置（JSON格式）
 	Size        string    `gorm:"size:100;not null" json:"size"`              // 大小（JSON格式）
-	DataSource  string    `gorm:"size:200" json:"data_source"`               // 数据源
-	Query       string    `gorm:"type:text" json:"query"`                     // 查询条件
+	DataSource  string    `gorm:"size:200" json:"data_source"`               // 数据源：metric, metric_history, alerts
+	Query       string    `gorm:"type:text" json:"query"`                     // 查询条件（JSON格式）
+	Thresholds  string    `gorm:"type:text" json:"thresholds"`                // 阈值（JSON格式）
 	RefreshInterval int   `gorm:"not null;default:30" json:"refresh_interval"` // 刷新间隔（秒）
 	Enabled     bool      `gorm:"not null;default:true" json:"enabled"`       // 是否启用
 	CreatedAt   time.Time `json:"created_at"`
@@ -162,6 +183,42 @@ type MonitoringReport struct {
 	CreatedBy   uint      `gorm:"not null" json:"created_by"`                 // 创建者ID
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	Schedule *MonitoringSchedule `gorm:"foreignKey:ScheduleID" json:"schedule,omitempty"`
+}
+
+// MonitoringReportFile 已生成的监控报告文件
+type MonitoringReportFile struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	ReportID      uint      `gorm:"not null;index" json:"report_id"`          // 报告ID
+	Format        string    `gorm:"size:20;not null" json:"format"`           // 格式：pdf, html, json
+	FileName      string    `gorm:"size:255;not null" json:"file_name"`       // 下载文件名
+	FilePath      string    `gorm:"size:500;not null" json:"-"`               // 保存路径
+	Size          int64     `gorm:"not null;default:0" json:"size"`           // 文件大小（字节）
+	PeriodStart   time.Time `gorm:"not null" json:"period_start"`             // 统计开始时间
+	PeriodEnd     time.Time `gorm:"not null" json:"period_end"`               // 统计结束时间
+	Trigger       string    `gorm:"size:20;not null" json:"trigger"`          // 触发方式：schedule, manual
+	Status        string    `gorm:"size:20;not null;index" json:"status"`     // 状态：generated, delivered, delivery_failed
+	DeliveryError string    `gorm:"size:1000" json:"delivery_error"`          // 投递失败原因
+	CreatedAt     time.Time `gorm:"index" json:"created_at"`
+}
+
+// MonitoringSLO 服务等级目标
+// 以指标采样计算SLI：满足 Comparison Threshold 的采样为合格采样，合格比例需达到 Objective
+type MonitoringSLO struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"size:100;not null;uniqueIndex" json:"name"`  // SLO名称
+	Description string    `gorm:"size:500" json:"description"`                 // 描述
+	Type        string    `gorm:"size:20;not null" json:"type"`                // 类型：availability, latency
+	Metric      string    `gorm:"size:200;not null" json:"metric"`             // 指标名称
+	Comparison  string    `gorm:"size:5;not null" json:"comparison"`           // 合格条件：>, >=, <, <=
+	Threshold   float64   `gorm:"not null" json:"threshold"`                   // 合格阈值
+	Objective   float64   `gorm:"not null" json:"objective"`                   // 目标（百分比），如 99.9
+	WindowDays  int       `gorm:"not null;default:30" json:"window_days"`      // 滚动统计窗口（天）
+	Enabled     bool      `gorm:"not null;default:true" json:"enabled"`        // 是否启用
+	CreatedBy   uint      `gorm:"not null" json:"created_by"`                  // 创建者ID
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 // MonitoringEvent 监控事件
@@ -183,6 +240,80 @@ type MonitoringEvent struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// MonitoringIncident 事件（Incident）
+// 同一告警规则在事件解决前触发的告警归入同一事件，事件的严重程度取其中告警的最高级别
+type MonitoringIncident struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	Title          string     `gorm:"size:200;not null" json:"title"`                // 标题，默认为首个告警的消息
+	RuleID         string     `gorm:"size:100;not null;index" json:"rule_id"`        // 告警引擎规则ID，用于归并告警
+	Severity       string     `gorm:"size:20;not null;index" json:"severity"`        // 严重程度：info, warning, error, critical
+	Status         string     `gorm:"size:20;not null;index" json:"status"`          // 状态：open, acknowledged, resolved
+	AlertCount     int        `gorm:"not null;default:0" json:"alert_count"`         // 归入的告警数
+	ActiveAlerts   int        `gorm:"not null;default:0" json:"active_alerts"`       // 尚未恢复的告警数
+	ReopenCount    int        `gorm:"not null;default:0" json:"reopen_count"`        // 重新打开次数
+	OpenedAt       time.Time  `gorm:"not null;index" json:"opened_at"`               // 打开时间
+	AcknowledgedAt *time.Time `json:"acknowledged_at"`                               // 首次确认时间
+	AcknowledgedBy *uint      `json:"acknowledged_by"`                               // 确认者ID
+	ResolvedAt     *time.Time `json:"resolved_at"`                                   // 解决时间
+	ResolvedBy     *uint      `json:"resolved_by"`                                   // 解决者ID，自动解决时为空
+	ResolutionNote string     `gorm:"type:text" json:"resolution_note"`              // 解决说明
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// MonitoringIncidentEvent 事件时间线记录
+type MonitoringIncidentEvent struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	IncidentID uint      `gorm:"not null;index" json:"incident_id"`  // 事件ID
+	Type       string    `gorm:"size:30;not null" json:"type"`       // 类型：opened, alert_triggered, alert_resolved, acknowledged, comment, resolved, reopened
+	AlertID    string    `gorm:"size:100;index" json:"alert_id"`     // 告警引擎的告警ID
+	UserID     *uint     `json:"user_id"`                            // 操作用户ID，系统记录时为空
+	Message    string    `gorm:"type:text" json:"message"`           // 说明或评论内容
+	Data       string    `gorm:"type:text" json:"data,omitempty"`    // 附加数据（JSON格式）
+	CreatedAt  time.Time `gorm:"not null;index" json:"created_at"`
+}
+
+// SilenceMatcher 静默匹配器，语义与 Alertmanager 相同
+// IsRegex 为 true 时按完整匹配的正则表达式比较，IsEqual 为 false 时取反（!= 和 !~），告警缺少的标签按空值比较
+type SilenceMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"is_regex"`
+	IsEqual bool   `json:"is_equal"`
+}
+
+// MonitoringSilence 告警静默
+// 开始到结束时间内标签满足全部匹配器的告警不发送通知；过期只把结束时间改为当前时间，记录保留
+type MonitoringSilence struct {
+	ID                  uint             `gorm:"primaryKey" json:"id"`
+	Matchers            []SilenceMatcher `gorm:"type:text;serializer:json" json:"matchers"` // 匹配器，全部满足时静默
+	StartsAt            time.Time        `gorm:"not null;index" json:"starts_at"`           // 开始时间
+	EndsAt              time.Time        `gorm:"not null;index" json:"ends_at"`             // 结束时间
+	CreatedBy           uint             `gorm:"not null;index" json:"created_by"`          // 创建者ID，维护窗口创建的静默为窗口创建者
+	Comment             string           `gorm:"type:text" json:"comment"`                  // 说明
+	MaintenanceWindowID *uint            `gorm:"index" json:"maintenance_window_id"`        // 关联的维护窗口，手动创建时为空
+	Status              string           `gorm:"-" json:"status"`                           // 状态：pending, active, expired，按当前时间计算
+	CreatedAt           time.Time        `json:"created_at"`
+	UpdatedAt           time.Time        `json:"updated_at"`
+}
+
+// MonitoringMaintenanceWindow 维护窗口
+// 单次窗口使用 StartsAt/EndsAt；设置 Schedule（cron表达式）时按计划重复，每次持续 DurationMinutes 分钟
+// 窗口的当前或下一次时段自动创建关联的静默
+type MonitoringMaintenanceWindow struct {
+	ID              uint             `gorm:"primaryKey" json:"id"`
+	Name            string           `gorm:"size:100;not null" json:"name"`              // 名称
+	Description     string           `gorm:"type:text" json:"description"`               // 描述
+	Matchers        []SilenceMatcher `gorm:"type:text;serializer:json" json:"matchers"`  // 静默匹配器
+	StartsAt        *time.Time       `json:"starts_at"`                                  // 单次窗口开始时间
+	EndsAt          *time.Time       `json:"ends_at"`                                    // 单次窗口结束时间
+	Schedule        string           `gorm:"size:100" json:"schedule"`                   // 重复窗口的cron表达式
+	DurationMinutes int              `gorm:"not null;default:0" json:"duration_minutes"` // 重复窗口每次持续的分钟数
+	CreatedBy       uint             `gorm:"not null;index" json:"created_by"`           // 创建者ID
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+}
+
 // TableName 指定表名
 func (MonitoringMetric) TableName() string {
 	return "monitoring_metrics"
@@ -216,6 +347,30 @@ func (MonitoringReport) TableName() string {
 	return "monitoring_reports"
 }
 
+func (MonitoringReportFile) TableName() string {
+	return "monitoring_report_files"
+}
+
+func (MonitoringSLO) TableName() string {
+	return "monitoring_slos"
+}
+
 func (MonitoringEvent) TableName() string {
 	return "monitoring_events"
 }
+
+func (MonitoringIncident) TableName() string {
+	return "monitoring_incidents"
+}
+
+func (MonitoringIncidentEvent) TableName() string {
+	return "monitoring_incident_events"
+}
+
+func (MonitoringSilence) TableName() string {
+	return "monitoring_silences"
+}
+
+func (MonitoringMaintenanceWindow) TableName() string {
+	return "monitoring_maintenance_windows"
+}
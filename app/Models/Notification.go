@@ -0,0 +1,68 @@
+package Models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 站内通知类型
+const (
+	NotificationTypeSystem           = "system"            // 系统通知
+	NotificationTypeBackup           = "backup"            // 备份完成或失败
+	NotificationTypeSecurityAlert    = "security_alert"    // 安全告警
+	NotificationTypePasswordExpiring = "password_expiring" // 密码即将过期
+	NotificationTypeImpersonation    = "impersonation"     // 管理员以用户身份登录
+	NotificationTypeMention          = "mention"           // 评论中被@提及
+	NotificationTypeApproval         = "approval"          // 敏感操作待审批或审批结果
+)
+
+// Notification 站内通知
+// 功能说明：
+// 1. 每个接收用户一条记录，系统事件发给多个用户时按用户拆分
+// 2. Data 为通知的附加数据（如备份ID、事件ID），以JSON保存在 Payload 列
+// 3. ReadAt 为空表示未读
+type Notification struct {
+	ID        uint                   `json:"id" gorm:"primarykey"`
+	UserID    uint                   `json:"user_id" gorm:"not null;index:idx_notifications_user_read,priority:1"` // 接收用户
+	Type      string                 `json:"type" gorm:"size:50;not null;index"`                                   // 通知类型
+	Title     string                 `json:"title" gorm:"size:255;not null"`                                       // 标题
+	Content   string                 `json:"content" gorm:"type:text"`                                             // 内容
+	Payload   string                 `json:"-" gorm:"type:text"`                                                   // 附加数据(JSON)
+	Data      map[string]interface{} `json:"data,omitempty" gorm:"-"`                                              // 附加数据
+	ReadAt    *time.Time             `json:"read_at" gorm:"index:idx_notifications_user_read,priority:2"`          // 阅读时间
+	CreatedAt time.Time              `json:"created_at" gorm:"index"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// BeforeSave 保存前将附加数据序列化到 Payload
+func (n *Notification) BeforeSave(tx *gorm.DB) error {
+	if n.Data == nil {
+		return nil
+	}
+	payload, err := json.Marshal(n.Data)
+	if err != nil {
+		return err
+	}
+	n.Payload = string(payload)
+	return nil
+}
+
+// AfterFind 查询后解析附加数据
+func (n *Notification) AfterFind(tx *gorm.DB) error {
+	if n.Payload == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(n.Payload), &n.Data)
+}
+
+// IsRead 是否已读
+func (n *Notification) IsRead() bool {
+	return n.ReadAt != nil
+}
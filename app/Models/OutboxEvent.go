@@ -0,0 +1,66 @@
+package Models
+
+import (
+	"strings"
+	"time"
+)
+
+// 发件箱事件状态
+const (
+	OutboxPending     = "pending"     // 等待投递（包括等待重试）
+	OutboxDispatching = "dispatching" // 已被分发器领取，租期内其他实例不会重复领取
+	OutboxDispatched  = "dispatched"  // 已投递到全部目标
+	OutboxFailed      = "failed"      // 超过最大投递次数
+)
+
+// OutboxEvent 发件箱中的领域事件
+// 功能说明：
+// 1. 与业务数据在同一事务中写入，事务回滚时事件一起回滚，提交后由分发器投递
+// 2. EventID 为全局唯一的事件ID，投递给处理器和外部消息系统，消费方据此去重
+// 3. Delivered 记录已成功投递的目标（逗号分隔），重试时跳过这些目标
+// 4. NextAttemptAt 为下次可以投递的时间，领取时推迟到租期结束，分发器崩溃后事件会被重新领取
+type OutboxEvent struct {
+	ID            uint       `json:"id" gorm:"primarykey"`
+	EventID       string     `json:"event_id" gorm:"size:36;not null;uniqueIndex"` // 事件ID
+	EventType     string     `json:"event_type" gorm:"size:100;not null;index"`    // 事件类型，如 user.registered
+	AggregateType string     `json:"aggregate_type" gorm:"size:50"`                // 聚合类型，如 user
+	AggregateID   string     `json:"aggregate_id" gorm:"size:64;index"`            // 聚合ID
+	Payload       string     `json:"payload" gorm:"type:text"`                     // 事件内容(JSON)
+	RequestID     string     `json:"request_id" gorm:"size:100"`                   // 产生事件的请求ID
+	CorrelationID string     `json:"correlation_id" gorm:"size:100"`               // 产生事件的请求的关联ID
+	Status        string     `json:"status" gorm:"size:20;not null;index"`         // 状态
+	Attempts      int        `json:"attempts"`                                     // 已投递次数
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"index"`                 // 下次投递时间
+	Delivered     string     `json:"delivered" gorm:"size:500"`                    // 已投递的目标
+	LastError     string     `json:"last_error" gorm:"type:text"`                  // 最近一次投递失败的原因
+	DispatchedAt  *time.Time `json:"dispatched_at"`                                // 全部投递完成时间
+	CreatedAt     time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+// DeliveredTo 是否已投递到指定目标
+func (e *OutboxEvent) DeliveredTo(target string) bool {
+	for _, name := range strings.Split(e.Delivered, ",") {
+		if name == target {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkDelivered 记录已投递的目标
+func (e *OutboxEvent) MarkDelivered(target string) {
+	if e.DeliveredTo(target) {
+		return
+	}
+	if e.Delivered == "" {
+		e.Delivered = target
+		return
+	}
+	e.Delivered += "," + target
+}
@@ -0,0 +1,63 @@
+package Models
+
+import (
+	"time"
+)
+
+// 短信投递状态
+const (
+	SMSStatusPending     = "pending"     // 已创建等待发送
+	SMSStatusSent        = "sent"        // 服务商已接收
+	SMSStatusDelivered   = "delivered"   // 回执确认已送达
+	SMSStatusUndelivered = "undelivered" // 回执确认未送达
+	SMSStatusFailed      = "failed"      // 提交服务商失败
+)
+
+// SMSMessage 短信投递记录
+// 功能说明：
+// 1. 每条短信一条记录，保存号码、发送方、模板、内容和投递状态
+// 2. 服务商返回的消息ID（阿里云BizId、Twilio MessageSid）用于关联送达回执
+// 3. 验证码短信的内容在保存前脱敏，不落库明文验证码
+type SMSMessage struct {
+	ID                uint       `json:"id" gorm:"primarykey"`
+	Driver            string     `json:"driver" gorm:"size:20"`                    // 发送驱动
+	Phone             string     `json:"phone" gorm:"size:32;not null;index"`      // 接收号码（E.164格式）
+	CountryCode       string     `json:"country_code" gorm:"size:8"`               // 国家区号
+	Sender            string     `json:"sender" gorm:"size:64"`                    // 发送方（短信签名或发送号码）
+	Template          string     `json:"template" gorm:"size:100;index"`           // 使用的模板
+	Content           string     `json:"content" gorm:"type:text"`                 // 短信内容
+	Status            string     `json:"status" gorm:"size:20;not null;index"`     // 投递状态
+	Attempts          int        `json:"attempts"`                                 // 已尝试次数
+	LastError         string     `json:"last_error" gorm:"type:text"`              // 最近一次错误
+	ProviderMessageID string     `json:"provider_message_id" gorm:"size:64;index"` // 服务商消息ID
+	ErrorCode         string     `json:"error_code" gorm:"size:64"`                // 回执中的错误码
+	SentAt            *time.Time `json:"sent_at"`                                  // 提交成功时间
+	DeliveredAt       *time.Time `json:"delivered_at"`                             // 回执时间
+	CreatedAt         time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (SMSMessage) TableName() string {
+	return "sms_messages"
+}
+
+// SMSTemplate 短信模板
+// 功能说明：
+// 1. Content 中使用 {{name}} 引用变量，发送时替换；Twilio等按内容发送的服务商直接使用替换后的内容
+// 2. 阿里云只能发送审核通过的模板，ProviderCode 为对应的模板CODE，变量以 TemplateParam 传递
+type SMSTemplate struct {
+	ID           uint      `json:"id" gorm:"primarykey"`
+	Name         string    `json:"name" gorm:"size:100;uniqueIndex;not null"` // 模板名称
+	Content      string    `json:"content" gorm:"type:text;not null"`         // 模板内容
+	ProviderCode string    `json:"provider_code" gorm:"size:64"`              // 服务商模板CODE（阿里云）
+	Description  string    `json:"description" gorm:"size:255"`               // 说明
+	Enabled      bool      `json:"enabled" gorm:"default:true"`               // 是否启用
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (SMSTemplate) TableName() string {
+	return "sms_templates"
+}
@@ -1,6 +1,7 @@
 package Models
 
 import (
+	"cloud-platform-api/app/Utils"
 	"time"
 
 	"gorm.io/gorm"
@@ -25,6 +26,8 @@ type SecurityEvent struct {
 	Location        string         `json:"location" gorm:"type:varchar(255)"`                           // 地理位置
 	DeviceInfo      string         `json:"device_info" gorm:"type:text"`                                 // 设备信息
 	SessionID       string         `json:"session_id" gorm:"type:varchar(100);index"`                    // 会话ID
+	RequestID       string         `json:"request_id" gorm:"type:varchar(100)"`                          // 请求ID
+	CorrelationID   string         `json:"correlation_id" gorm:"type:varchar(100);index"`                // 关联ID
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `json:"deleted_at" gorm:"index"`
@@ -65,6 +68,7 @@ type AccessControl struct {
 	DeviceRestriction string       `json:"device_restriction" gorm:"type:text"`                        // 设备限制
 	Priority        int            `json:"priority" gorm:"default:0"`                                   // 优先级
 	Active          bool           `json:"active" gorm:"default:true"`                                  // 是否活跃
+	Version         uint           `json:"version" gorm:"not null;default:1"`                           // 版本号（乐观锁）
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `json:"deleted_at" gorm:"index"`
@@ -209,6 +213,17 @@ func (SecurityEvent) TableName() string {
 	return "security_events"
 }
 
+// BeforeCreate 未设置请求ID和关联ID时从数据库操作的上下文（db.WithContext）中获取
+func (e *SecurityEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.RequestID == "" {
+		e.RequestID = Utils.RequestIDFromContext(tx.Statement.Context)
+	}
+	if e.CorrelationID == "" {
+		e.CorrelationID = Utils.CorrelationIDFromContext(tx.Statement.Context)
+	}
+	return nil
+}
+
 func (ThreatIntelligence) TableName() string {
 	return "threat_intelligence"
 }
@@ -0,0 +1,28 @@
+package Models
+
+import (
+	"time"
+)
+
+// SecurityArchiveFile 安全数据归档文件索引
+// 功能说明：
+// 1. 每个归档文件一条记录，记录文件的表、时间范围 [From, To]、主键范围、记录数和SHA-256校验值
+// 2. 对象键唯一，同一批记录重新归档时更新原记录；多个实例同时归档时各自写入自己的记录，不会相互覆盖
+type SecurityArchiveFile struct {
+	ID         uint      `json:"-" gorm:"primarykey"`
+	Key        string    `json:"key" gorm:"column:object_key;size:500;not null;uniqueIndex"` // 归档文件对象键
+	Table      string    `json:"table" gorm:"column:table_name;size:100;not null;index"`     // 来源表
+	Rows       int64     `json:"rows" gorm:"column:row_count"`                               // 记录数
+	FirstID    uint      `json:"first_id"`                                                   // 文件中最小的主键
+	LastID     uint      `json:"last_id"`                                                    // 文件中最大的主键
+	From       time.Time `json:"from" gorm:"column:range_start;index"`                       // 文件中最早的记录时间
+	To         time.Time `json:"to" gorm:"column:range_end;index"`                           // 文件中最晚的记录时间
+	Size       int64     `json:"size"`                                                       // 压缩文件字节数
+	Checksum   string    `json:"checksum" gorm:"size:64"`                                    // 压缩文件的SHA-256
+	ArchivedAt time.Time `json:"archived_at"`                                                // 归档时间
+}
+
+// TableName 指定表名
+func (SecurityArchiveFile) TableName() string {
+	return "security_archive_files"
+}
@@ -0,0 +1,14 @@
+package Models
+
+import "time"
+
+// SecurityPostureSnapshot 安全态势评分快照
+// 定期保存总分和各项分数，用于展示评分趋势
+type SecurityPostureSnapshot struct {
+	ID             uint               `gorm:"primaryKey" json:"id"`
+	Score          float64            `gorm:"not null" json:"score"`        // 总分，0-100
+	Grade          string             `gorm:"size:2;not null" json:"grade"` // 等级：A、B、C、D、F，没有可用数据时为空
+	Categories     string             `gorm:"type:text;not null" json:"-"`  // 各项分数，JSON对象，键为分类
+	CategoryScores map[string]float64 `gorm:"-" json:"categories"`          // 各项分数，查询时从 Categories 解析
+	CreatedAt      time.Time          `gorm:"index" json:"created_at"`      // 评分时间
+}
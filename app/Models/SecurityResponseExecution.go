@@ -0,0 +1,46 @@
+package Models
+
+import (
+	"time"
+)
+
+// 自动响应动作执行状态
+const (
+	ResponseStatusPendingApproval = "pending_approval" // 等待人工审批
+	ResponseStatusApproved        = "approved"         // 审批通过，正在执行
+	ResponseStatusExecuted        = "executed"         // 已执行
+	ResponseStatusDryRun          = "dry_run"          // 演练模式，未实际执行
+	ResponseStatusFailed          = "failed"           // 执行失败
+	ResponseStatusRejected        = "rejected"         // 审批拒绝
+	ResponseStatusExpired         = "expired"          // 审批超时
+)
+
+// SecurityResponseExecution 自动响应动作执行记录
+// 功能说明：
+// 1. 剧本中每个动作的每次触发对应一条记录，保存触发事件、动作参数和执行结果
+// 2. 需要审批的动作先以待审批状态记录，审批通过后执行并更新状态
+type SecurityResponseExecution struct {
+	ID         uint       `json:"id" gorm:"primarykey"`
+	Playbook   string     `json:"playbook" gorm:"size:100;not null;index"` // 剧本名称
+	Action     string     `json:"action" gorm:"size:50;not null"`          // 动作类型
+	Params     string     `json:"params" gorm:"type:text"`                 // 动作参数(JSON)
+	EventType  string     `json:"event_type" gorm:"size:50;index"`         // 触发事件类型
+	EventID    uint       `json:"event_id" gorm:"index"`                   // 触发的安全事件ID
+	UserID     *uint      `json:"user_id" gorm:"index"`                    // 事件关联用户ID
+	IPAddress  string     `json:"ip_address" gorm:"size:45;index"`         // 事件关联IP
+	RiskScore  float64    `json:"risk_score"`                              // 事件风险评分
+	Status     string     `json:"status" gorm:"size:20;not null;index"`    // 执行状态
+	Result     string     `json:"result" gorm:"type:text"`                 // 执行结果说明
+	Error      string     `json:"error" gorm:"type:text"`                  // 错误信息
+	ReviewedBy *uint      `json:"reviewed_by"`                             // 审批人ID
+	ReviewedAt *time.Time `json:"reviewed_at"`                             // 审批时间
+	ExecutedAt *time.Time `json:"executed_at"`                             // 执行时间
+	ExpiresAt  *time.Time `json:"expires_at"`                              // 审批截止时间
+	CreatedAt  time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (SecurityResponseExecution) TableName() string {
+	return "security_response_executions"
+}
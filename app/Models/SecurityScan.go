@@ -0,0 +1,47 @@
+package Models
+
+import "time"
+
+// SecurityScanResult 安全扫描结果
+// 每次扫描执行所有已注册的扫描插件，发现的漏洞和插件错误以JSON保存
+type SecurityScanResult struct {
+	ID              string            `json:"id" gorm:"primaryKey;size:36"`
+	ScanType        string            `json:"scan_type" gorm:"size:50"`
+	Target          string            `json:"target" gorm:"size:200"`
+	Vulnerabilities []Vulnerability   `json:"vulnerabilities" gorm:"type:text;serializer:json"`
+	Errors          map[string]string `json:"errors,omitempty" gorm:"type:text;serializer:json"` // 执行失败的插件及错误信息
+	RiskScore       int               `json:"risk_score"`
+	Status          string            `json:"status" gorm:"size:20;index"`
+	ScanTime        time.Time         `json:"scan_time" gorm:"index"`
+	CreatedAt       time.Time         `json:"created_at"`
+}
+
+// Vulnerability 漏洞信息
+type Vulnerability struct {
+	ID             string   `json:"id,omitempty"`      // 漏洞编号，如 GO-2024-0001、CVE-2024-0001
+	Scanner        string   `json:"scanner,omitempty"` // 发现漏洞的扫描插件
+	Type           string   `json:"type"`
+	Severity       string   `json:"severity"` // critical、high、medium、low
+	Description    string   `json:"description"`
+	Location       string   `json:"location"`
+	Recommendation string   `json:"recommendation"`
+	References     []string `json:"references,omitempty"` // 相关链接或别名
+}
+
+// DependencyVulnerability 依赖漏洞跟踪记录
+// 定期用依赖清单查询漏洞库，每个漏洞和模块一条记录；不再被报告时（如已升级依赖）设置 ResolvedAt
+type DependencyVulnerability struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	VulnID         string     `json:"vuln_id" gorm:"size:100;not null;uniqueIndex:idx_dependency_vuln_module"` // 漏洞编号，如 GO-2024-0001
+	Module         string     `json:"module" gorm:"size:255;not null;uniqueIndex:idx_dependency_vuln_module"`
+	Version        string     `json:"version" gorm:"size:100"` // 最近一次检查时的模块版本
+	Severity       string     `json:"severity" gorm:"size:20;index"`
+	Summary        string     `json:"summary" gorm:"type:text"`
+	Recommendation string     `json:"recommendation" gorm:"type:text"`
+	References     []string   `json:"references" gorm:"type:text;serializer:json"`
+	FirstSeenAt    time.Time  `json:"first_seen_at"`
+	LastSeenAt     time.Time  `json:"last_seen_at"`
+	ResolvedAt     *time.Time `json:"resolved_at" gorm:"index"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,51 @@
+package Models
+
+import "time"
+
+// 签名客户端状态
+const (
+	SigningClientActive   = "active"   // 可以签名调用
+	SigningClientDisabled = "disabled" // 已停用，签名请求返回401
+)
+
+// SigningClient 请求签名客户端
+// 功能说明：
+// 1. 服务间调用使用 KeyID 标识客户端，使用 Secret 计算 HMAC-SHA256 请求签名
+// 2. 请求以 UserID 对应用户的身份执行，用户名和角色与JWT认证相同，权限检查不需要区分认证方式
+// 3. 轮换后旧密钥保存在 PreviousSecret，PreviousSecretExpiresAt 之前仍可验证签名
+// 4. 密钥只在创建和轮换时返回给调用方
+type SigningClient struct {
+	ID                      uint       `json:"id" gorm:"primarykey"`
+	Name                    string     `json:"name" gorm:"size:100;not null"`              // 客户端名称，如调用方服务名
+	Description             string     `json:"description" gorm:"size:500"`                // 描述
+	KeyID                   string     `json:"key_id" gorm:"size:64;not null;uniqueIndex"` // 公开的客户端标识，出现在 Authorization 请求头中
+	Secret                  string     `json:"-" gorm:"size:128;not null"`                 // 当前签名密钥
+	PreviousSecret          string     `json:"-" gorm:"size:128"`                          // 轮换前的签名密钥
+	PreviousSecretExpiresAt *time.Time `json:"previous_secret_expires_at"`                 // 旧密钥失效时间
+	UserID                  uint       `json:"user_id" gorm:"not null;index"`              // 请求以该用户身份执行
+	Status                  string     `json:"status" gorm:"size:20;not null;index"`       // 状态
+	CreatedBy               uint       `json:"created_by" gorm:"index"`                    // 创建人
+	RotatedAt               *time.Time `json:"rotated_at"`                                 // 最近一次轮换密钥的时间
+	LastUsedAt              *time.Time `json:"last_used_at"`                               // 最近一次签名验证通过的时间
+	CreatedAt               time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt               time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (SigningClient) TableName() string {
+	return "signing_clients"
+}
+
+// SigningNonce 已使用的请求随机数
+// 同一客户端的随机数在 ExpiresAt 之前只能使用一次，超过时间戳允许偏差的请求本身就会被拒绝，过期后可以清理
+type SigningNonce struct {
+	ID        uint      `gorm:"primarykey"`
+	ClientID  uint      `gorm:"not null;uniqueIndex:idx_signing_nonce_client_nonce"`
+	Nonce     string    `gorm:"size:128;not null;uniqueIndex:idx_signing_nonce_client_nonce"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+// TableName 指定表名
+func (SigningNonce) TableName() string {
+	return "signing_nonces"
+}
@@ -0,0 +1,43 @@
+package Models
+
+import (
+	"time"
+)
+
+// SlowQueryStat 慢查询统计模型
+// 功能说明：
+// 1. 按规范化后的SQL（参数替换为?）聚合，同一语句只有一条记录
+// 2. 记录调用次数、慢查询次数、累计/最大/P95耗时和影响行数
+// 3. 保存一条最慢的完整SQL样本，用于 EXPLAIN 分析执行计划
+//
+// 耗时单位均为毫秒；P95 按最近的耗时样本计算。
+type SlowQueryStat struct {
+	ID            uint      `json:"id" gorm:"primarykey"`
+	Fingerprint   string    `json:"fingerprint" gorm:"size:40;uniqueIndex;not null"` // 规范化SQL的SHA1
+	NormalizedSQL string    `json:"normalized_sql" gorm:"type:text;not null"`        // 规范化后的SQL
+	SampleSQL     string    `json:"sample_sql" gorm:"type:text"`                     // 最慢一次执行的完整SQL
+	Table         string    `json:"table" gorm:"size:100;index"`                     // 主表名
+	CallCount     int64     `json:"call_count" gorm:"not null;default:0"`            // 调用次数
+	SlowCount     int64     `json:"slow_count" gorm:"not null;default:0"`            // 超过慢查询阈值的次数
+	TotalTime     float64   `json:"total_time" gorm:"not null;default:0"`            // 累计耗时
+	MaxTime       float64   `json:"max_time" gorm:"not null;default:0"`              // 最大耗时
+	P95Time       float64   `json:"p95_time" gorm:"not null;default:0;index"`        // P95耗时
+	RowsAffected  int64     `json:"rows_affected" gorm:"not null;default:0"`         // 累计影响行数
+	FirstSeenAt   time.Time `json:"first_seen_at"`                                   // 首次出现时间
+	LastSeenAt    time.Time `json:"last_seen_at" gorm:"index"`                       // 最近出现时间
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (SlowQueryStat) TableName() string {
+	return "slow_query_stats"
+}
+
+// AvgTime 平均耗时（毫秒）
+func (s *SlowQueryStat) AvgTime() float64 {
+	if s.CallCount == 0 {
+		return 0
+	}
+	return s.TotalTime / float64(s.CallCount)
+}
@@ -0,0 +1,43 @@
+package Models
+
+import (
+	"time"
+)
+
+// 分区状态
+const (
+	TablePartitionLive     = "live"     // 数据仍在数据库中
+	TablePartitionArchived = "archived" // 已导出到归档存储并从数据库删除
+)
+
+// 分区方式
+const (
+	TablePartitionNative = "native" // PostgreSQL原生范围分区
+	TablePartitionTable  = "table"  // 按月轮转的独立月表（MySQL、SQLite）
+)
+
+// TablePartition 大表分区记录
+// 功能说明：
+// 1. 记录每个分区覆盖的时间范围 [RangeStart, RangeEnd)，查询按时间范围选择需要读取的分区
+// 2. 归档后记录归档文件的对象键、大小、行数和SHA-256校验值，分区本身已从数据库删除
+type TablePartition struct {
+	ID            uint       `json:"id" gorm:"primarykey"`
+	Table         string     `json:"table" gorm:"column:table_name;size:100;not null;index"` // 所属表
+	PartitionName string     `json:"partition_name" gorm:"size:120;not null;uniqueIndex"`    // 分区表名
+	Strategy      string     `json:"strategy" gorm:"size:20;not null"`                       // 分区方式：native、table
+	RangeStart    time.Time  `json:"range_start" gorm:"not null"`                            // 时间范围下界（包含）
+	RangeEnd      time.Time  `json:"range_end" gorm:"not null;index"`                        // 时间范围上界（不包含）
+	Status        string     `json:"status" gorm:"size:20;not null;index"`                   // 状态：live、archived
+	RowCount      int64      `json:"row_count"`                                              // 归档时的行数
+	ArchiveKey    string     `json:"archive_key" gorm:"size:500"`                            // 归档文件对象键
+	ArchiveSize   int64      `json:"archive_size"`                                           // 归档文件字节数
+	Checksum      string     `json:"checksum" gorm:"size:64"`                                // 归档文件SHA-256
+	ArchivedAt    *time.Time `json:"archived_at"`                                            // 归档时间
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (TablePartition) TableName() string {
+	return "table_partitions"
+}
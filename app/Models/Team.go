@@ -0,0 +1,67 @@
+package Models
+
+import "time"
+
+// 团队成员角色
+const (
+	TeamRoleAdmin  = "admin"  // 团队管理员：邀请和移除成员、修改成员角色
+	TeamRoleMember = "member" // 普通成员：查看和管理团队的仪表板、告警规则和API密钥
+)
+
+// Organization 组织
+// 组织所有者可以创建团队并管理组织下的所有团队
+type Organization struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"size:100;not null;uniqueIndex" json:"name"` // 组织名称
+	Description string    `gorm:"size:500" json:"description"`               // 描述
+	OwnerID     uint      `gorm:"not null;index" json:"owner_id"`            // 所有者ID
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Team 团队
+// 仪表板、告警规则和API密钥可以归属团队，团队成员共同查看和管理
+type Team struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID uint      `gorm:"not null;uniqueIndex:idx_teams_org_name" json:"organization_id"` // 所属组织ID
+	Name           string    `gorm:"size:100;not null;uniqueIndex:idx_teams_org_name" json:"name"`   // 团队名称，组织内唯一
+	Description    string    `gorm:"size:500" json:"description"`                                    // 描述
+	CreatedBy      uint      `gorm:"not null" json:"created_by"`                                     // 创建者ID
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	Members []TeamMember `gorm:"foreignKey:TeamID" json:"members,omitempty"` // 成员
+}
+
+// TeamMember 团队成员
+type TeamMember struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	TeamID    uint      `gorm:"not null;uniqueIndex:idx_team_members_team_user" json:"team_id"`       // 团队ID
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_team_members_team_user;index" json:"user_id"` // 用户ID
+	Role      string    `gorm:"size:20;not null;default:'member'" json:"role"`                        // 角色：admin、member
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"` // 用户
+}
+
+// TeamInvitation 团队邀请
+// 邀请令牌只保存哈希，明文令牌只出现在邀请邮件的链接中
+type TeamInvitation struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	TeamID     uint       `gorm:"not null;index" json:"team_id"`                 // 团队ID
+	Email      string     `gorm:"size:255;not null;index" json:"email"`          // 被邀请的邮箱
+	Role       string     `gorm:"size:20;not null;default:'member'" json:"role"` // 接受后的成员角色
+	TokenHash  string     `gorm:"size:64;not null;uniqueIndex" json:"-"`         // 邀请令牌哈希（SHA-256）
+	InvitedBy  uint       `gorm:"not null" json:"invited_by"`                    // 邀请人ID
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`                    // 过期时间
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`                         // 接受时间
+	AcceptedBy *uint      `json:"accepted_by,omitempty"`                         // 接受邀请的用户ID
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// Pending 邀请是否仍可接受
+func (i *TeamInvitation) Pending(now time.Time) bool {
+	return i.AcceptedAt == nil && now.Before(i.ExpiresAt)
+}
@@ -0,0 +1,28 @@
+package Models
+
+import "time"
+
+// UsageRecord 租户每天的用量
+// 功能说明：
+// 1. 每个租户每个用量指标每天一条记录，Quantity 为当天累计用量，用于限额检查和计费导出
+// 2. 租户为 user:<用户ID> 或 source:<指标推送来源>，日期为UTC日期
+type UsageRecord struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	Tenant    string    `json:"tenant" gorm:"size:100;not null;uniqueIndex:idx_usage_records_tenant_metric_date"`    // 租户
+	Metric    string    `json:"metric" gorm:"size:50;not null;uniqueIndex:idx_usage_records_tenant_metric_date"`     // 用量指标，如 api_calls
+	Date      string    `json:"date" gorm:"size:10;not null;uniqueIndex:idx_usage_records_tenant_metric_date;index"` // 日期，格式 2006-01-02
+	Quantity  int64     `json:"quantity" gorm:"not null;default:0"`                                                  // 当天累计用量
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UsageQuota 租户单独设置的用量限额，未设置的租户使用配置中的默认限额
+type UsageQuota struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	Tenant    string    `json:"tenant" gorm:"size:100;not null;uniqueIndex:idx_usage_quotas_tenant_metric"` // 租户
+	Metric    string    `json:"metric" gorm:"size:50;not null;uniqueIndex:idx_usage_quotas_tenant_metric"`  // 用量指标
+	Limit     int64     `json:"limit" gorm:"column:quota_limit;not null"`                                   // 每周期限额，0表示不限制
+	UpdatedBy uint      `json:"updated_by"`                                                                 // 设置限额的管理员ID
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -62,6 +62,13 @@ type User struct {
 	EmailVerifiedAt *time.Time `json:"email_verified_at" gorm:"index"`          // 邮箱验证时间
 	LastLoginAt     *time.Time `json:"last_login_at" gorm:"index"`              // 最后登录时间
 	LoginCount      int        `json:"login_count" gorm:"default:0"`            // 登录次数
+	Locale          string     `json:"locale" gorm:"size:20"`                   // 偏好语言（为空时按请求头解析）
+	Phone           string     `json:"phone" gorm:"size:32;index"`              // 手机号（E.164格式），用于短信MFA验证
+
+	// 密码过期策略
+	PasswordChangedAt        *time.Time `json:"password_changed_at"`                             // 最后修改密码时间（为空时按创建时间计算）
+	MustChangePassword       bool       `json:"must_change_password" gorm:"default:false;index"` // 是否必须修改密码后才能访问其他接口
+	PasswordExpiryNotifiedAt *time.Time `json:"-"`                                               // 最近一次发送密码过期提醒的时间
 
 	// 关联关系
 	Posts []Post `json:"posts,omitempty" gorm:"foreignKey:UserID"` // 用户发布的文章
@@ -0,0 +1,46 @@
+package Models
+
+import (
+	"math"
+	"time"
+)
+
+// UserBehaviorProfile 用户行为画像模型
+// 功能说明：
+// 1. 每个用户一条记录，随用户行为增量更新，不需要重新扫描历史事件
+// 2. 记录登录时段分布、常用IP和网段、常用接口的访问次数
+// 3. 用Welford算法维护每分钟请求数的均值和方差，作为请求频率基线
+//
+// IP、网段和接口只保留访问次数最多的若干项，避免画像无限增长。
+type UserBehaviorProfile struct {
+	ID            uint             `json:"id" gorm:"primarykey"`
+	UserID        uint             `json:"user_id" gorm:"uniqueIndex;not null"`           // 用户ID
+	LoginHours    [24]int64        `json:"login_hours" gorm:"type:text;serializer:json"`  // 各小时的登录次数
+	LoginCount    int64            `json:"login_count" gorm:"not null;default:0"`         // 累计登录次数
+	IPAddresses   map[string]int64 `json:"ip_addresses" gorm:"type:text;serializer:json"` // 常用IP及访问次数
+	Networks      map[string]int64 `json:"networks" gorm:"type:text;serializer:json"`     // 常用网段（ASN或IP前缀）及访问次数
+	Endpoints     map[string]int64 `json:"endpoints" gorm:"type:text;serializer:json"`    // 常用接口及访问次数
+	EventCount    int64            `json:"event_count" gorm:"not null;default:0"`         // 累计事件数
+	RateMean      float64          `json:"rate_mean" gorm:"not null;default:0"`           // 活跃分钟的平均请求数
+	RateM2        float64          `json:"-" gorm:"column:rate_m2;not null;default:0"`    // 请求数与均值差的平方和
+	RateSamples   int64            `json:"rate_samples" gorm:"not null;default:0"`        // 已统计的活跃分钟数
+	CurrentMinute time.Time        `json:"current_minute"`                                // 当前统计的分钟
+	CurrentCount  int64            `json:"current_count" gorm:"not null;default:0"`       // 当前分钟的请求数
+	FirstSeenAt   time.Time        `json:"first_seen_at"`                                 // 首次出现时间
+	LastSeenAt    time.Time        `json:"last_seen_at" gorm:"index"`                     // 最近出现时间
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (UserBehaviorProfile) TableName() string {
+	return "user_behavior_profiles"
+}
+
+// RateStdDev 每分钟请求数的标准差
+func (p *UserBehaviorProfile) RateStdDev() float64 {
+	if p.RateSamples < 2 {
+		return 0
+	}
+	return math.Sqrt(p.RateM2 / float64(p.RateSamples-1))
+}
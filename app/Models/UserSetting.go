@@ -0,0 +1,18 @@
+package Models
+
+import (
+	"time"
+)
+
+// UserSetting 用户偏好设置
+// 功能说明：
+// 1. 每个用户每个设置项一条记录，Value 为JSON编码的值，类型和可选值由 UserSettingsService 中的设置项定义校验
+// 2. 只保存用户修改过的设置项，未保存的设置项使用配置中的默认值，恢复默认即删除记录
+type UserSetting struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_user_settings_user_key"`      // 用户ID
+	Key       string    `json:"key" gorm:"size:100;not null;uniqueIndex:idx_user_settings_user_key"` // 设置项，如 notifications.sms
+	Value     string    `json:"value" gorm:"type:text"`                                              // JSON编码的值
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
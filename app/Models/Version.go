@@ -0,0 +1,99 @@
+package Models
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ErrVersionConflict 乐观锁版本冲突
+var ErrVersionConflict = errors.New("版本冲突")
+
+// Versioned 带版本号的模型
+// 功能说明：
+// 1. 版本号在每次通过 SaveWithVersion 更新时递增
+// 2. 嵌入 BaseModel 的模型自动实现，AlertRule 和 AccessControl 单独实现
+type Versioned interface {
+	GetID() uint
+	GetVersion() uint
+	SetVersion(version uint)
+}
+
+// VersionConflictError 版本冲突错误，包含数据库中的当前版本
+type VersionConflictError struct {
+	Expected uint // 客户端期望的版本
+	Current  uint // 数据库中的当前版本
+}
+
+// Error 错误信息
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%v：期望版本 %d，当前版本 %d", ErrVersionConflict, e.Expected, e.Current)
+}
+
+// Unwrap 支持 errors.Is(err, ErrVersionConflict)
+func (e *VersionConflictError) Unwrap() error {
+	return ErrVersionConflict
+}
+
+// SaveWithVersion 使用乐观锁保存模型的全部字段
+// 功能说明：
+// 1. 只有数据库中的版本等于 expected 时才写入，写入后版本号加一
+// 2. expected 为 0 时使用模型加载时的版本，防止读取和写入之间的并发修改被覆盖
+// 3. 版本不一致时返回 *VersionConflictError，模型的版本号保持不变
+// 4. 记录已被删除时返回 gorm.ErrRecordNotFound
+func SaveWithVersion(db *gorm.DB, model Versioned, expected uint) error {
+	if expected == 0 {
+		expected = model.GetVersion()
+	}
+	loaded := model.GetVersion()
+	model.SetVersion(expected + 1)
+	result := db.Model(model).Where("version = ?", expected).Select("*").Omit("created_at").Updates(model)
+	if result.Error != nil {
+		model.SetVersion(loaded)
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	model.SetVersion(loaded)
+	var versions []uint
+	if err := db.Model(model).Where("id = ?", model.GetID()).Pluck("version", &versions).Error; err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return &VersionConflictError{Expected: expected, Current: versions[0]}
+}
+
+// GetID 获取ID
+func (r *AlertRule) GetID() uint {
+	return r.ID
+}
+
+// GetVersion 获取版本号
+func (r *AlertRule) GetVersion() uint {
+	return r.Version
+}
+
+// SetVersion 设置版本号
+func (r *AlertRule) SetVersion(version uint) {
+	r.Version = version
+}
+
+// GetID 获取ID
+func (a *AccessControl) GetID() uint {
+	return a.ID
+}
+
+// GetVersion 获取版本号
+func (a *AccessControl) GetVersion() uint {
+	return a.Version
+}
+
+// SetVersion 设置版本号
+func (a *AccessControl) SetVersion(version uint) {
+	a.Version = version
+}
@@ -0,0 +1,104 @@
+package Models
+
+import (
+	"strings"
+	"time"
+)
+
+// Webhook订阅状态
+const (
+	WebhookSubscriptionActive = "active" // 正常投递
+	WebhookSubscriptionPaused = "paused" // 已暂停，新事件照常生成投递记录，恢复后继续发送
+)
+
+// Webhook投递状态
+const (
+	WebhookDeliveryPending    = "pending"    // 等待发送
+	WebhookDeliveryDelivering = "delivering" // 正在发送
+	WebhookDeliveryRetrying   = "retrying"   // 发送失败，等待重试
+	WebhookDeliverySucceeded  = "succeeded"  // 对方返回2xx
+	WebhookDeliveryFailed     = "failed"     // 重试次数用尽
+)
+
+// WebhookSubscription Webhook订阅
+// 功能说明：
+// 1. EventTypes 为订阅的事件类型（逗号分隔），支持 * 和 alert.* 这样的前缀通配
+// 2. Filter 为事件内容的筛选条件(JSON对象)，键为事件 payload 的字段，值为期望的值或可选值数组
+// 3. Secret 用于对请求体签名，只在创建和轮换时返回给调用方
+type WebhookSubscription struct {
+	ID                  uint       `json:"id" gorm:"primarykey"`
+	Name                string     `json:"name" gorm:"size:100;not null"`        // 订阅名称
+	Description         string     `json:"description" gorm:"size:500"`          // 描述
+	URL                 string     `json:"url" gorm:"size:500;not null"`         // 接收地址
+	Secret              string     `json:"-" gorm:"size:128;not null"`           // 签名密钥
+	EventTypes          string     `json:"event_types" gorm:"size:500;not null"` // 订阅的事件类型
+	Filter              string     `json:"filter" gorm:"type:text"`              // 事件内容筛选条件(JSON)
+	Status              string     `json:"status" gorm:"size:20;not null;index"` // 状态
+	CreatedBy           uint       `json:"created_by" gorm:"index"`              // 创建人
+	ConsecutiveFailures int        `json:"consecutive_failures"`                 // 连续投递失败次数，成功后清零
+	LastDeliveryStatus  string     `json:"last_delivery_status" gorm:"size:20"`  // 最近一次投递结果
+	LastDeliveryAt      *time.Time `json:"last_delivery_at"`                     // 最近一次投递时间
+	PausedAt            *time.Time `json:"paused_at"`                            // 暂停时间
+	CreatedAt           time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// EventTypeList 订阅的事件类型列表
+func (s *WebhookSubscription) EventTypeList() []string {
+	var types []string
+	for _, eventType := range strings.Split(s.EventTypes, ",") {
+		if eventType = strings.TrimSpace(eventType); eventType != "" {
+			types = append(types, eventType)
+		}
+	}
+	return types
+}
+
+// Matches 是否订阅了指定事件类型
+func (s *WebhookSubscription) Matches(eventType string) bool {
+	for _, pattern := range s.EventTypeList() {
+		if pattern == "*" || pattern == eventType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(eventType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery Webhook投递记录
+// 功能说明：
+// 1. 每个事件对每个匹配的订阅生成一条记录，同一事件不会重复投递到同一订阅
+// 2. Payload 为发送的请求体，重试时原样发送，接收方按 DeliveryID 或事件ID去重
+// 3. 保存最近一次请求的响应状态码、响应内容（截断）和耗时，用于排查问题
+type WebhookDelivery struct {
+	ID             uint       `json:"id" gorm:"primarykey"`
+	DeliveryID     string     `json:"delivery_id" gorm:"size:36;not null;uniqueIndex"`                                    // 投递ID
+	SubscriptionID uint       `json:"subscription_id" gorm:"not null;uniqueIndex:idx_webhook_delivery_event,priority:1"`  // 订阅ID
+	EventID        string     `json:"event_id" gorm:"size:36;not null;uniqueIndex:idx_webhook_delivery_event,priority:2"` // 事件ID
+	EventType      string     `json:"event_type" gorm:"size:100;not null;index"`                                          // 事件类型
+	Payload        string     `json:"payload" gorm:"type:text"`                                                           // 请求体(JSON)
+	RequestID      string     `json:"request_id" gorm:"size:100"`                                                         // 产生事件的请求ID
+	CorrelationID  string     `json:"correlation_id" gorm:"size:100"`                                                     // 产生事件的请求的关联ID
+	Status         string     `json:"status" gorm:"size:20;not null;index"`                                               // 投递状态
+	Attempts       int        `json:"attempts"`                                                                           // 已发送次数
+	NextAttemptAt  *time.Time `json:"next_attempt_at" gorm:"index"`                                                       // 下次发送时间
+	ResponseStatus int        `json:"response_status"`                                                                    // 最近一次响应状态码
+	ResponseBody   string     `json:"response_body" gorm:"type:text"`                                                     // 最近一次响应内容（截断）
+	DurationMs     int64      `json:"duration_ms"`                                                                        // 最近一次请求耗时
+	LastError      string     `json:"last_error" gorm:"type:text"`                                                        // 最近一次错误
+	DeliveredAt    *time.Time `json:"delivered_at"`                                                                       // 发送成功时间
+	CreatedAt      time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
@@ -0,0 +1,132 @@
+// Package OpenAPI 接口文档
+// 功能说明：
+// 1. 路由通过 Registry 注册时同时声明请求体、查询参数和响应的Go类型，文档由这些类型反射生成，不会与实现脱节
+// 2. 生成 OpenAPI 3.0 文档，包含统一响应信封、错误响应、分页参数和认证方式
+// 3. 按文档校验请求，严格模式下拒绝未声明的字段和查询参数，用于测试
+package OpenAPI
+
+// Version 生成的文档遵循的 OpenAPI 版本
+const Version = "3.0.3"
+
+// Document OpenAPI 文档
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Tags       []Tag                `json:"tags,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+// Info 文档信息
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server 服务地址
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// Tag 接口分组
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem 同一路径下各请求方法的接口
+type PathItem struct {
+	Get     *Operation `json:"get,omitempty"`
+	Put     *Operation `json:"put,omitempty"`
+	Post    *Operation `json:"post,omitempty"`
+	Delete  *Operation `json:"delete,omitempty"`
+	Patch   *Operation `json:"patch,omitempty"`
+	Head    *Operation `json:"head,omitempty"`
+	Options *Operation `json:"options,omitempty"`
+}
+
+// Operation 接口
+type Operation struct {
+	OperationID  string                `json:"operationId,omitempty"`
+	Summary      string                `json:"summary,omitempty"`
+	Description  string                `json:"description,omitempty"`
+	Tags         []string              `json:"tags,omitempty"`
+	Parameters   []*Parameter          `json:"parameters,omitempty"`
+	RequestBody  *RequestBody          `json:"requestBody,omitempty"`
+	Responses    map[string]*Response  `json:"responses"`
+	Security     []map[string][]string `json:"security,omitempty"`
+	Undocumented bool                  `json:"x-undocumented,omitempty"` // 未通过 Registry 声明的路由
+}
+
+// Parameter 路径、查询或请求头参数
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // path、query、header
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Style       string  `json:"style,omitempty"`
+	Explode     *bool   `json:"explode,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody 请求体
+type RequestBody struct {
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+// Response 响应
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType 请求体或响应的内容
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components 可复用的定义
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme 认证方式
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// Schema 数据结构，JSON Schema 的 OpenAPI 3.0 子集
+// 空的 Schema 表示任意值；$ref 不能与其他关键字并列，可为空的引用写作 allOf 加 nullable
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
@@ -0,0 +1,616 @@
+package OpenAPI
+
+import (
+	"cloud-platform-api/app/Utils"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 认证方式
+const (
+	BearerAuth = "bearerAuth" // 请求头 Authorization: Bearer <JWT>
+)
+
+// Route 路由的接口声明
+// 请求体、查询参数和响应使用类型的零值声明，文档由类型反射生成
+type Route struct {
+	Summary     string
+	Description string
+	Request     interface{}      // 请求体类型，如 Requests.LoginRequest{}，为 nil 表示没有请求体
+	Query       interface{}      // 查询参数结构体，字段使用 form 标签
+	Params      []Param          // 路径参数说明，未声明的路径参数按字符串处理
+	List        *Utils.QuerySpec // 列表接口的查询白名单，生成分页、筛选、排序和字段选择参数，响应为列表格式
+	Response    interface{}      // 响应 data 字段的类型，列表接口为单条记录的类型，为 nil 时 data 为任意值
+	Status      int              // 成功状态码，默认200
+	Errors      []int            // 可能返回的错误状态码，400、401、403、500 按声明自动添加
+	Raw         bool             // 响应不使用统一格式，如文件下载和SSE
+	Public      bool             // 分组需要认证时，此路由不需要认证
+}
+
+// Param 路径参数
+type Param struct {
+	Name        string
+	Description string
+	Type        string // integer 或 string，默认 string
+}
+
+// PathID 正整数ID路径参数
+func PathID(name, description string) Param {
+	return Param{Name: name, Description: description, Type: "integer"}
+}
+
+// routeEntry 已注册的路由
+type routeEntry struct {
+	method   string
+	path     string // gin 路由路径，如 /api/v1/users/:id
+	tag      string
+	security []string
+	handler  string
+	route    Route
+}
+
+// Registry 路由和接口声明的注册表
+// 功能说明：
+// 1. 通过 Group 注册的路由同时注册到 gin 和注册表，文档始终与实际路由一致
+// 2. Document 生成 OpenAPI 3.0 文档，传入 gin 的路由表时未声明的路由也会列出并标记 x-undocumented
+// 3. 生成的文档按注册内容缓存，注册新路由后重新生成
+type Registry struct {
+	mu       sync.RWMutex
+	info     Info
+	servers  []Server
+	tags     []Tag
+	entries  map[string]*routeEntry
+	order    []string
+	compiled *compiledDocument
+	// allowedQuery 全局中间件使用的查询参数（如语言参数 lang），严格校验时不视为未声明
+	allowedQuery map[string]bool
+}
+
+// compiledDocument 已声明路由生成的文档和用于请求校验的索引
+type compiledDocument struct {
+	document   *Document
+	operations map[string]*Operation // 键为 "METHOD gin路径"
+}
+
+// NewRegistry 创建注册表
+func NewRegistry(info Info) *Registry {
+	return &Registry{info: info, entries: make(map[string]*routeEntry), allowedQuery: make(map[string]bool)}
+}
+
+var (
+	defaultRegistry   = NewRegistry(Info{Title: "Cloud Platform API", Version: "1.0.0"})
+	defaultRegistryMu sync.RWMutex
+)
+
+// SetDefaultRegistry 设置全局注册表，路由文件通过 DefaultRegistry 注册接口
+func SetDefaultRegistry(registry *Registry) {
+	defaultRegistryMu.Lock()
+	defer defaultRegistryMu.Unlock()
+	defaultRegistry = registry
+}
+
+// DefaultRegistry 获取全局注册表
+func DefaultRegistry() *Registry {
+	defaultRegistryMu.RLock()
+	defer defaultRegistryMu.RUnlock()
+	return defaultRegistry
+}
+
+// SetServers 设置文档中的服务地址
+func (r *Registry) SetServers(servers ...Server) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.servers = servers
+	r.compiled = nil
+}
+
+// AddTag 添加接口分组说明，未添加说明的分组按注册顺序列出
+func (r *Registry) AddTag(name, description string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.compiled = nil
+	for i := range r.tags {
+		if r.tags[i].Name == name {
+			r.tags[i].Description = description
+			return
+		}
+	}
+	r.tags = append(r.tags, Tag{Name: name, Description: description})
+}
+
+// AllowQueryParams 声明全局中间件使用的查询参数，所有接口都可以携带
+func (r *Registry) AllowQueryParams(names ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, name := range names {
+		r.allowedQuery[name] = true
+	}
+}
+
+// add 记录路由，同一方法和路径重复注册时覆盖
+func (r *Registry) add(entry *routeEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := entry.method + " " + entry.path
+	if _, ok := r.entries[key]; !ok {
+		r.order = append(r.order, key)
+	}
+	r.entries[key] = entry
+	r.compiled = nil
+}
+
+// Group 路由分组
+// 同一分组的接口使用相同的文档分组和认证方式
+type Group struct {
+	registry *Registry
+	group    *gin.RouterGroup
+	tag      string
+	security []string
+}
+
+// Group 包装 gin 路由分组，tag 为文档中的接口分组，security 为分组的认证方式
+func (r *Registry) Group(group *gin.RouterGroup, tag string, security ...string) *Group {
+	return &Group{registry: r, group: group, tag: tag, security: security}
+}
+
+// Group 创建子分组，沿用文档分组和认证方式
+func (g *Group) Group(relativePath string, handlers ...gin.HandlerFunc) *Group {
+	return &Group{registry: g.registry, group: g.group.Group(relativePath, handlers...), tag: g.tag, security: g.security}
+}
+
+// Use 添加分组中间件
+func (g *Group) Use(middleware ...gin.HandlerFunc) *Group {
+	g.group.Use(middleware...)
+	return g
+}
+
+// Handle 注册路由并记录接口声明，handlers 的最后一个为处理函数
+func (g *Group) Handle(method, relativePath string, route Route, handlers ...gin.HandlerFunc) {
+	g.group.Handle(method, relativePath, handlers...)
+	entry := &routeEntry{
+		method:   method,
+		path:     joinPaths(g.group.BasePath(), relativePath),
+		tag:      g.tag,
+		security: g.security,
+		route:    route,
+	}
+	if len(handlers) > 0 {
+		entry.handler = handlerName(handlers[len(handlers)-1])
+	}
+	g.registry.add(entry)
+}
+
+// GET 注册 GET 路由
+func (g *Group) GET(relativePath string, route Route, handlers ...gin.HandlerFunc) {
+	g.Handle(http.MethodGet, relativePath, route, handlers...)
+}
+
+// POST 注册 POST 路由
+func (g *Group) POST(relativePath string, route Route, handlers ...gin.HandlerFunc) {
+	g.Handle(http.MethodPost, relativePath, route, handlers...)
+}
+
+// PUT 注册 PUT 路由
+func (g *Group) PUT(relativePath string, route Route, handlers ...gin.HandlerFunc) {
+	g.Handle(http.MethodPut, relativePath, route, handlers...)
+}
+
+// PATCH 注册 PATCH 路由
+func (g *Group) PATCH(relativePath string, route Route, handlers ...gin.HandlerFunc) {
+	g.Handle(http.MethodPatch, relativePath, route, handlers...)
+}
+
+// DELETE 注册 DELETE 路由
+func (g *Group) DELETE(relativePath string, route Route, handlers ...gin.HandlerFunc) {
+	g.Handle(http.MethodDelete, relativePath, route, handlers...)
+}
+
+// Document 生成文档，routes 为 gin 的路由表（engine.Routes()），为 nil 时只包含已声明的路由
+func (r *Registry) Document(routes gin.RoutesInfo) *Document {
+	compiled := r.compile()
+	doc := *compiled.document
+	if len(routes) == 0 {
+		return &doc
+	}
+
+	// 复制路径表，补充未声明的路由
+	doc.Paths = make(map[string]*PathItem, len(compiled.document.Paths))
+	for p, item := range compiled.document.Paths {
+		copied := *item
+		doc.Paths[p] = &copied
+	}
+	for _, route := range routes {
+		if _, ok := compiled.operations[route.Method+" "+route.Path]; ok {
+			continue
+		}
+		op := &Operation{
+			Summary:      shortHandlerName(route.Handler),
+			Parameters:   pathParameters(route.Path, nil),
+			Responses:    map[string]*Response{"default": {Description: "未声明的接口"}},
+			Undocumented: true,
+		}
+		item := doc.Paths[openAPIPath(route.Path)]
+		if item == nil {
+			item = &PathItem{}
+			doc.Paths[openAPIPath(route.Path)] = item
+		}
+		item.set(route.Method, op)
+	}
+	return &doc
+}
+
+// Handler 返回文档的处理函数，engine 不为 nil 时包含未声明的路由
+func (r *Registry) Handler(engine *gin.Engine) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var routes gin.RoutesInfo
+		if engine != nil {
+			routes = engine.Routes()
+		}
+		ctx.JSON(http.StatusOK, r.Document(routes))
+	}
+}
+
+// WriteFile 把文档写入文件
+func (r *Registry) WriteFile(filename string, routes gin.RoutesInfo) error {
+	data, err := json.MarshalIndent(r.Document(routes), "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(filename); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(filename, append(data, '\n'), 0644)
+}
+
+// operation 查找已声明的接口，fullPath 为 gin 路由路径
+func (r *Registry) operation(method, fullPath string) (*Operation, *Components) {
+	compiled := r.compile()
+	op := compiled.operations[method+" "+fullPath]
+	return op, &compiled.document.Components
+}
+
+// queryAllowed 查询参数是否为全局参数
+func (r *Registry) queryAllowed(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.allowedQuery[name]
+}
+
+// compile 生成已声明路由的文档，结果缓存到下次注册路由
+func (r *Registry) compile() *compiledDocument {
+	r.mu.RLock()
+	if compiled := r.compiled; compiled != nil {
+		r.mu.RUnlock()
+		return compiled
+	}
+	r.mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.compiled != nil {
+		return r.compiled
+	}
+
+	builder := newSchemaBuilder()
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    r.info,
+		Servers: r.servers,
+		Tags:    append([]Tag(nil), r.tags...),
+		Paths:   make(map[string]*PathItem),
+	}
+	operations := make(map[string]*Operation, len(r.order))
+	operationIDs := make(map[string]bool)
+	security := make(map[string]bool)
+
+	for _, key := range r.order {
+		entry := r.entries[key]
+		op := builder.operation(entry)
+		op.OperationID = uniqueOperationID(operationIDs, entry)
+		for _, scheme := range entry.security {
+			security[scheme] = true
+		}
+		if entry.tag != "" && !hasTag(doc.Tags, entry.tag) {
+			doc.Tags = append(doc.Tags, Tag{Name: entry.tag})
+		}
+
+		item := doc.Paths[openAPIPath(entry.path)]
+		if item == nil {
+			item = &PathItem{}
+			doc.Paths[openAPIPath(entry.path)] = item
+		}
+		item.set(entry.method, op)
+		operations[key] = op
+	}
+
+	builder.components[errorResponseSchema] = errorSchema()
+	doc.Components.Schemas = builder.components
+	if len(security) > 0 {
+		doc.Components.SecuritySchemes = make(map[string]*SecurityScheme)
+		for scheme := range security {
+			doc.Components.SecuritySchemes[scheme] = securityScheme(scheme)
+		}
+	}
+
+	r.compiled = &compiledDocument{document: doc, operations: operations}
+	return r.compiled
+}
+
+// errorResponseSchema 错误响应的组件名称
+const errorResponseSchema = "ErrorResponse"
+
+// errorSchema 错误响应格式，与 Controller.Error 和 Controller.ValidationError 一致
+func errorSchema() *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean", Enum: []interface{}{false}},
+			"message": {Type: "string"},
+			"error":   {Type: "string", Description: "错误详情"},
+			"errors":  {Description: "参数校验失败时的字段错误"},
+		},
+		Required: []string{"success", "message"},
+	}
+}
+
+// securityScheme 认证方式的定义
+func securityScheme(name string) *SecurityScheme {
+	switch name {
+	case BearerAuth:
+		return &SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "登录接口返回的令牌"}
+	default:
+		return &SecurityScheme{Type: "apiKey", In: "header", Name: name}
+	}
+}
+
+// operation 生成接口文档
+func (b *schemaBuilder) operation(entry *routeEntry) *Operation {
+	route := entry.route
+	op := &Operation{
+		Summary:     route.Summary,
+		Description: route.Description,
+		Parameters:  pathParameters(entry.path, route.Params),
+		Responses:   make(map[string]*Response),
+	}
+	if entry.tag != "" {
+		op.Tags = []string{entry.tag}
+	}
+	secured := len(entry.security) > 0 && !route.Public
+	if secured {
+		requirement := make(map[string][]string, len(entry.security))
+		for _, scheme := range entry.security {
+			requirement[scheme] = []string{}
+		}
+		op.Security = []map[string][]string{requirement}
+	}
+
+	if route.List != nil {
+		op.Parameters = append(op.Parameters, listParameters(*route.List)...)
+	}
+	if route.Query != nil {
+		op.Parameters = append(op.Parameters, b.queryParameters(reflect.TypeOf(route.Query))...)
+	}
+	if route.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"application/json": {Schema: b.schemaOf(reflect.TypeOf(route.Request))}},
+		}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &Response{Description: http.StatusText(status)}
+	if !route.Raw && status != http.StatusNoContent {
+		success.Content = map[string]*MediaType{"application/json": {Schema: b.envelope(route)}}
+	}
+	op.Responses[fmt.Sprint(status)] = success
+
+	errors := append([]int(nil), route.Errors...)
+	if len(op.Parameters) > 0 || op.RequestBody != nil {
+		errors = append(errors, http.StatusBadRequest)
+	}
+	if secured {
+		errors = append(errors, http.StatusUnauthorized, http.StatusForbidden)
+	}
+	errors = append(errors, http.StatusInternalServerError)
+	for _, code := range errors {
+		op.Responses[fmt.Sprint(code)] = &Response{
+			Description: http.StatusText(code),
+			Content:     map[string]*MediaType{"application/json": {Schema: &Schema{Ref: schemaRefPrefix + errorResponseSchema}}},
+		}
+	}
+	return op
+}
+
+// envelope 统一响应格式，列表接口的 data 为数组并包含分页信息 meta
+func (b *schemaBuilder) envelope(route Route) *Schema {
+	var data *Schema
+	if route.Response != nil {
+		data = b.schemaOf(reflect.TypeOf(route.Response))
+	} else {
+		data = &Schema{}
+	}
+	s := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean", Enum: []interface{}{true}},
+			"message": {Type: "string"},
+			"data":    data,
+		},
+		Required: []string{"success", "message"},
+	}
+	if route.List != nil {
+		s.Properties["data"] = &Schema{Type: "array", Items: data}
+		s.Properties["meta"] = b.schemaOf(reflect.TypeOf(Utils.PageMeta{}))
+		s.Required = append(s.Required, "data", "meta")
+	}
+	return s
+}
+
+// listParameters 列表接口的分页、筛选、排序和字段选择参数，与 Controller.ParsePageRequest 和 Utils.ParseListQuery 一致
+func listParameters(spec Utils.QuerySpec) []*Parameter {
+	var filters, sorts, fields []string
+	filterSchema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for name, field := range spec.Fields {
+		fields = append(fields, name)
+		if field.Sort {
+			sorts = append(sorts, name)
+		}
+		if field.Filter {
+			filters = append(filters, name)
+			filterSchema.Properties[name] = &Schema{Type: "string", Description: "字段类型 " + field.Type}
+		}
+	}
+	sort.Strings(filters)
+	sort.Strings(sorts)
+	sort.Strings(fields)
+
+	explode := true
+	params := []*Parameter{
+		{Name: "page", In: "query", Description: "页码，页码分页使用", Schema: &Schema{Type: "integer", Minimum: float64Ptr(1), Default: 1}},
+		{Name: "limit", In: "query", Description: "每页数量，最大100", Schema: &Schema{Type: "integer", Minimum: float64Ptr(1), Maximum: float64Ptr(100), Default: 20}},
+		{Name: "page_size", In: "query", Description: "每页数量，limit 的别名", Schema: &Schema{Type: "integer", Minimum: float64Ptr(1), Maximum: float64Ptr(100)}},
+		{Name: "cursor", In: "query", Description: "上一页返回的 meta.cursor，传入时使用游标分页", Schema: &Schema{Type: "string"}},
+		{Name: "pagination", In: "query", Description: "分页模式", Schema: &Schema{Type: "string", Enum: []interface{}{Utils.PageModeOffset, Utils.PageModeCursor}}},
+		{Name: "with_total", In: "query", Description: "是否统计总数，页码分页默认统计", Schema: &Schema{Type: "boolean"}},
+		{Name: "fields", In: "query", Description: "返回字段，逗号分隔，可选: " + strings.Join(fields, ", "), Schema: &Schema{Type: "string"}},
+	}
+	if len(sorts) > 0 {
+		sortParam := &Parameter{Name: "sort", In: "query", Description: "排序字段，逗号分隔，前缀 - 表示降序，可选: " + strings.Join(sorts, ", "), Schema: &Schema{Type: "string"}}
+		if spec.DefaultSort != "" {
+			sortParam.Schema.Default = spec.DefaultSort
+		}
+		params = append(params, sortParam)
+	}
+	if len(filters) > 0 {
+		params = append(params, &Parameter{
+			Name:        "filter",
+			In:          "query",
+			Description: "筛选条件 filter[field]=value 或 filter[field][op]=value，op 为 eq/ne/gt/gte/lt/lte/like/in/null",
+			Style:       "deepObject",
+			Explode:     &explode,
+			Schema:      filterSchema,
+		})
+	}
+	return params
+}
+
+// pathParameters 路径参数，declared 中未声明的参数按字符串处理
+func pathParameters(ginPath string, declared []Param) []*Parameter {
+	var params []*Parameter
+	for _, segment := range strings.Split(ginPath, "/") {
+		if len(segment) < 2 || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+		param := &Parameter{Name: segment[1:], In: "path", Required: true, Schema: &Schema{Type: "string"}}
+		for _, d := range declared {
+			if d.Name != param.Name {
+				continue
+			}
+			param.Description = d.Description
+			if d.Type == "integer" {
+				param.Schema = &Schema{Type: "integer", Format: "int64", Minimum: float64Ptr(1)}
+			}
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// openAPIPath 把 gin 路径 /users/:id 转换为 /users/{id}
+func openAPIPath(ginPath string) string {
+	segments := strings.Split(ginPath, "/")
+	for i, segment := range segments {
+		if len(segment) > 1 && (segment[0] == ':' || segment[0] == '*') {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// joinPaths 与 gin 拼接分组路径的规则一致，相对路径以 / 结尾时保留结尾的 /
+func joinPaths(absolutePath, relativePath string) string {
+	if relativePath == "" {
+		return absolutePath
+	}
+	finalPath := path.Join(absolutePath, relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(finalPath, "/") {
+		return finalPath + "/"
+	}
+	return finalPath
+}
+
+// set 设置请求方法对应的接口
+func (p *PathItem) set(method string, op *Operation) {
+	switch method {
+	case http.MethodGet:
+		p.Get = op
+	case http.MethodPut:
+		p.Put = op
+	case http.MethodPost:
+		p.Post = op
+	case http.MethodDelete:
+		p.Delete = op
+	case http.MethodPatch:
+		p.Patch = op
+	case http.MethodHead:
+		p.Head = op
+	case http.MethodOptions:
+		p.Options = op
+	}
+}
+
+// handlerName 处理函数的完整名称
+func handlerName(handler gin.HandlerFunc) string {
+	return runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
+}
+
+// shortHandlerName 去掉包路径和方法值后缀，如 WebhookController.GetSubscriptions
+func shortHandlerName(name string) string {
+	name = strings.TrimSuffix(name, "-fm")
+	if index := strings.LastIndex(name, "/"); index >= 0 {
+		name = name[index+1:]
+	}
+	if _, after, ok := strings.Cut(name, "."); ok {
+		name = after
+	}
+	return strings.NewReplacer("(", "", ")", "", "*", "").Replace(name)
+}
+
+// uniqueOperationID 以处理函数名作为 operationId，重复时追加请求方法
+func uniqueOperationID(used map[string]bool, entry *routeEntry) string {
+	id := shortHandlerName(entry.handler)
+	if id == "" || strings.Contains(id, "func") || used[id] {
+		id = strings.ToLower(entry.method) + strings.NewReplacer("/", "_", ":", "", "*", "", "-", "_").Replace(entry.path)
+	}
+	for base, i := id, 2; used[id]; i++ {
+		id = fmt.Sprintf("%s_%d", base, i)
+	}
+	used[id] = true
+	return id
+}
+
+// hasTag 分组是否已存在
+func hasTag(tags []Tag, name string) bool {
+	for _, tag := range tags {
+		if tag.Name == name {
+			return true
+		}
+	}
+	return false
+}
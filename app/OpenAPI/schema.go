@@ -0,0 +1,332 @@
+package OpenAPI
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// schemaRefPrefix 组件引用前缀
+const schemaRefPrefix = "#/components/schemas/"
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	deletedAtType     = reflect.TypeOf(gorm.DeletedAt{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+	// componentNamePattern 组件名称只能包含字母、数字和 ._-
+	componentNamePattern = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+// schemaBuilder 由Go类型生成 Schema
+// 功能说明：
+// 1. 字段名取 json 标签，json:"-" 的字段不输出，匿名嵌入的结构体字段展开到外层
+// 2. 具名结构体放入 components，以 包名.类型名 引用，支持递归类型
+// 3. binding 标签转换为约束：required、min/max/len、gt/gte/lt/lte、oneof、email、url、uuid
+type schemaBuilder struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{components: make(map[string]*Schema), names: make(map[reflect.Type]string)}
+}
+
+// schemaOf 生成类型的 Schema，指针类型可以为 null
+func (b *schemaBuilder) schemaOf(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+	s := b.build(t)
+	if !nullable {
+		return s
+	}
+	if s.Ref != "" {
+		return &Schema{AllOf: []*Schema{s}, Nullable: true}
+	}
+	s.Nullable = true
+	return s
+}
+
+// build 生成非指针类型的 Schema
+func (b *schemaBuilder) build(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case deletedAtType:
+		return &Schema{Type: "string", Format: "date-time", Nullable: true}
+	case rawMessageType:
+		return &Schema{}
+	}
+	// 自定义JSON编码的类型无法从字段推断结构
+	if t.Kind() != reflect.Slice && (t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType)) {
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32", Minimum: float64Ptr(0)}
+	case reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64", Minimum: float64Ptr(0)}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		return b.ref(t)
+	default:
+		return &Schema{}
+	}
+}
+
+// ref 把具名结构体放入 components 并返回引用
+func (b *schemaBuilder) ref(t reflect.Type) *Schema {
+	name, ok := b.names[t]
+	if !ok {
+		name = b.componentName(t)
+		b.names[t] = name
+		// 先占位，递归引用自身时直接返回引用
+		b.components[name] = &Schema{}
+		*b.components[name] = *b.object(t)
+	}
+	return &Schema{Ref: schemaRefPrefix + name}
+}
+
+// componentName 组件名称，如 Models.User；不同包的同名类型追加序号
+func (b *schemaBuilder) componentName(t reflect.Type) string {
+	name := t.Name()
+	if pkg := path.Base(t.PkgPath()); pkg != "." && pkg != "" {
+		name = pkg + "." + name
+	}
+	name = strings.Trim(componentNamePattern.ReplaceAllString(name, "_"), "_")
+	unique := name
+	for i := 2; b.components[unique] != nil; i++ {
+		unique = fmt.Sprintf("%s_%d", name, i)
+	}
+	return unique
+}
+
+// object 生成结构体的 Schema
+func (b *schemaBuilder) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	b.addFields(s, t)
+	return s
+}
+
+// addFields 添加结构体字段，匿名嵌入且没有 json 名称的结构体展开到外层
+func (b *schemaBuilder) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := jsonFieldName(field)
+		if !ok {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				b.addFields(s, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := b.schemaOf(field.Type)
+		if applyBinding(property, field.Tag.Get("binding")) && !slices.Contains(s.Required, name) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = property
+	}
+}
+
+// queryParameters 由结构体的 form 标签生成查询参数
+func (b *schemaBuilder) queryParameters(t reflect.Type) []*Parameter {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var params []*Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("form"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			params = append(params, b.queryParameters(field.Type)...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema := b.schemaOf(field.Type)
+		param := &Parameter{
+			Name:     name,
+			In:       "query",
+			Required: applyBinding(schema, field.Tag.Get("binding")),
+			Schema:   schema,
+		}
+		// map 字段使用 name[key]=value 形式，与 gin 的 QueryMap 一致
+		if field.Type.Kind() == reflect.Map {
+			explode := true
+			param.Style = "deepObject"
+			param.Explode = &explode
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// jsonFieldName 解析 json 标签，ok 为 false 表示字段不输出
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	return strings.Split(tag, ",")[0], true
+}
+
+// applyBinding 把 binding 标签转换为 Schema 约束，返回字段是否必填
+// dive 之后的规则作用于数组元素，不再处理；引用类型只处理 required
+func applyBinding(s *Schema, tag string) bool {
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		if name == "dive" {
+			break
+		}
+		if name == "required" {
+			required = true
+			continue
+		}
+		if s.Ref != "" || len(s.AllOf) > 0 {
+			continue
+		}
+
+		switch name {
+		case "min", "gte":
+			setLowerBound(s, value, false)
+		case "max", "lte":
+			setUpperBound(s, value, false)
+		case "gt":
+			setLowerBound(s, value, true)
+		case "lt":
+			setUpperBound(s, value, true)
+		case "len":
+			setLowerBound(s, value, false)
+			setUpperBound(s, value, false)
+		case "oneof":
+			for _, option := range strings.Fields(value) {
+				s.Enum = append(s.Enum, enumValue(s.Type, option))
+			}
+		case "email":
+			s.Format = "email"
+		case "url", "uri":
+			s.Format = "uri"
+		case "uuid", "uuid4":
+			s.Format = "uuid"
+		}
+	}
+	return required
+}
+
+// setLowerBound 设置下限：字符串为长度，数组为元素数，数字为取值
+func setLowerBound(s *Schema, value string, exclusive bool) {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+	switch s.Type {
+	case "string":
+		s.MinLength = intPtr(int(n))
+	case "array":
+		s.MinItems = intPtr(int(n))
+	case "integer", "number":
+		s.Minimum = float64Ptr(n)
+		s.ExclusiveMinimum = exclusive
+	}
+}
+
+// setUpperBound 设置上限：字符串为长度，数组为元素数，数字为取值
+func setUpperBound(s *Schema, value string, exclusive bool) {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+	switch s.Type {
+	case "string":
+		s.MaxLength = intPtr(int(n))
+	case "array":
+		s.MaxItems = intPtr(int(n))
+	case "integer", "number":
+		s.Maximum = float64Ptr(n)
+		s.ExclusiveMaximum = exclusive
+	}
+}
+
+// enumValue oneof 的可选值按字段类型转换
+func enumValue(schemaType, value string) interface{} {
+	switch schemaType {
+	case "integer":
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	}
+	return value
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func float64Ptr(n float64) *float64 {
+	return &n
+}
@@ -0,0 +1,366 @@
+package OpenAPI
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ValidationError 请求或响应不符合接口文档
+type ValidationError struct {
+	Errors []string
+}
+
+// Error 实现 error 接口
+func (e *ValidationError) Error() string {
+	return "不符合接口文档: " + strings.Join(e.Errors, "; ")
+}
+
+// validator 一次请求或响应的校验状态
+type validator struct {
+	components *Components
+	strict     bool
+	response   bool // 校验响应：Go 的空切片和空 map 编码为 null，数组和对象允许 null
+	errors     []string
+}
+
+// Validate 按接口文档校验请求
+// 功能说明：
+// 1. 校验路径参数、查询参数和JSON请求体的类型、必填字段、取值范围和可选值
+// 2. strict 为 true 时拒绝未声明的查询参数和请求体字段、非 JSON 的请求体，以及不可为 null 的字段传 null
+// 3. 未通过 Registry 声明的路由不校验；请求体读取后重新放回，处理函数仍可读取
+// 校验失败返回 *ValidationError
+func (r *Registry) Validate(ctx *gin.Context, strict bool) error {
+	op, components := r.operation(ctx.Request.Method, ctx.FullPath())
+	if op == nil {
+		return nil
+	}
+	v := &validator{components: components, strict: strict}
+
+	query := ctx.Request.URL.Query()
+	declared := make(map[string]bool)
+	deepObjects := make(map[string]bool)
+	for _, param := range op.Parameters {
+		switch param.In {
+		case "path":
+			v.validateParam(param, []string{ctx.Param(param.Name)}, "路径参数 "+param.Name)
+		case "query":
+			declared[param.Name] = true
+			if param.Style == "deepObject" {
+				deepObjects[param.Name] = true
+				continue
+			}
+			values, ok := query[param.Name]
+			if !ok {
+				if param.Required {
+					v.addf("缺少查询参数 %s", param.Name)
+				}
+				continue
+			}
+			v.validateParam(param, values, "查询参数 "+param.Name)
+		}
+	}
+	if strict {
+		keys := make([]string, 0, len(query))
+		for key := range query {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			name, _, _ := strings.Cut(key, "[")
+			if declared[key] || (deepObjects[name] && name != key) || r.queryAllowed(key) {
+				continue
+			}
+			v.addf("未声明的查询参数 %s", key)
+		}
+	}
+
+	if err := v.validateBody(ctx, op.RequestBody); err != nil {
+		return err
+	}
+	if len(v.errors) > 0 {
+		return &ValidationError{Errors: v.errors}
+	}
+	return nil
+}
+
+// ValidateResponse 按接口文档校验响应，用于契约测试
+// 功能说明：
+// 1. fullPath 为 gin 路由路径（如 /api/v1/users/:id），未通过 Registry 声明的路由不校验
+// 2. 响应状态码必须在文档中声明
+// 3. JSON 响应体按严格模式校验：不允许未声明的字段，字段类型、格式和可选值必须与文档一致
+// 校验失败返回 *ValidationError
+func (r *Registry) ValidateResponse(method, fullPath string, status int, body []byte) error {
+	op, components := r.operation(method, fullPath)
+	if op == nil {
+		return nil
+	}
+	response, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		return &ValidationError{Errors: []string{fmt.Sprintf("响应状态码 %d 未在接口文档中声明", status)}}
+	}
+	media := response.Content["application/json"]
+	if media == nil {
+		return nil
+	}
+
+	v := &validator{components: components, strict: true, response: true}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		v.addf("响应体不是有效的JSON: %v", err)
+	} else {
+		v.validateValue(media.Schema, value, "响应体")
+	}
+	if len(v.errors) > 0 {
+		return &ValidationError{Errors: v.errors}
+	}
+	return nil
+}
+
+// validateBody 校验JSON请求体
+func (v *validator) validateBody(ctx *gin.Context, body *RequestBody) error {
+	var data []byte
+	if ctx.Request.Body != nil {
+		var err error
+		data, err = io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			return err
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(data))
+	}
+
+	if body == nil {
+		if v.strict && len(bytes.TrimSpace(data)) > 0 {
+			v.addf("接口不接受请求体")
+		}
+		return nil
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		if body.Required {
+			v.addf("缺少请求体")
+		}
+		return nil
+	}
+	if v.strict {
+		if mediaType, _, _ := mime.ParseMediaType(ctx.GetHeader("Content-Type")); mediaType != "application/json" {
+			v.addf("请求体的 Content-Type 应为 application/json")
+		}
+	}
+
+	media := body.Content["application/json"]
+	if media == nil {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		v.addf("请求体不是有效的JSON: %v", err)
+		return nil
+	}
+	v.validateValue(media.Schema, value, "请求体")
+	return nil
+}
+
+// validateParam 按参数类型转换后校验
+func (v *validator) validateParam(param *Parameter, values []string, at string) {
+	schema := v.resolve(param.Schema)
+	if schema.Type == "array" {
+		items := make([]interface{}, 0, len(values))
+		for _, value := range values {
+			items = append(items, v.parseParam(v.resolve(schema.Items), value))
+		}
+		v.validateValue(schema, items, at)
+		return
+	}
+	if len(values) > 1 && v.strict {
+		v.addf("%s 不能重复", at)
+	}
+	v.validateValue(schema, v.parseParam(schema, values[0]), at)
+}
+
+// parseParam 把参数字符串转换为与JSON解析结果相同的类型，无法转换时保留字符串由校验报告类型错误
+func (v *validator) parseParam(schema *Schema, value string) interface{} {
+	switch schema.Type {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return json.Number(value)
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// resolve 解析组件引用和只有一项的 allOf
+func (v *validator) resolve(schema *Schema) *Schema {
+	for schema != nil {
+		if schema.Ref != "" {
+			schema = v.components.Schemas[strings.TrimPrefix(schema.Ref, schemaRefPrefix)]
+		} else if len(schema.AllOf) == 1 && schema.Type == "" {
+			schema = schema.AllOf[0]
+		} else {
+			break
+		}
+	}
+	if schema == nil {
+		return &Schema{}
+	}
+	return schema
+}
+
+// validateValue 按 Schema 校验值，at 为出错位置
+func (v *validator) validateValue(schema *Schema, value interface{}, at string) {
+	nullable := schema != nil && schema.Nullable
+	schema = v.resolve(schema)
+	if value == nil {
+		if v.response && (schema.Type == "array" || schema.AdditionalProperties != nil) {
+			return
+		}
+		if v.strict && !nullable && !schema.Nullable && schema.Type != "" {
+			v.addf("%s 不能为 null", at)
+		}
+		return
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			v.addf("%s 应为对象", at)
+			return
+		}
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				v.addf("%s 缺少字段 %s", at, name)
+			}
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if property, ok := schema.Properties[key]; ok {
+				v.validateValue(property, object[key], at+"."+key)
+			} else if schema.AdditionalProperties != nil {
+				v.validateValue(schema.AdditionalProperties, object[key], at+"."+key)
+			} else if v.strict {
+				v.addf("%s 包含未声明的字段 %s", at, key)
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			v.addf("%s 应为数组", at)
+			return
+		}
+		if schema.MinItems != nil && len(items) < *schema.MinItems {
+			v.addf("%s 至少需要 %d 项", at, *schema.MinItems)
+		}
+		if schema.MaxItems != nil && len(items) > *schema.MaxItems {
+			v.addf("%s 最多 %d 项", at, *schema.MaxItems)
+		}
+		for i, item := range items {
+			v.validateValue(schema.Items, item, fmt.Sprintf("%s[%d]", at, i))
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			v.addf("%s 应为字符串", at)
+			return
+		}
+		length := utf8.RuneCountInString(s)
+		if schema.MinLength != nil && length < *schema.MinLength {
+			v.addf("%s 长度不能少于 %d", at, *schema.MinLength)
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			v.addf("%s 长度不能超过 %d", at, *schema.MaxLength)
+		}
+		v.validateFormat(schema.Format, s, at)
+		v.validateEnum(schema, s, at)
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			v.addf("%s 应为数字", at)
+			return
+		}
+		n, err := number.Float64()
+		if err != nil {
+			v.addf("%s 应为数字", at)
+			return
+		}
+		if schema.Type == "integer" {
+			if _, err := number.Int64(); err != nil {
+				v.addf("%s 应为整数", at)
+				return
+			}
+		}
+		if schema.Minimum != nil && (n < *schema.Minimum || (schema.ExclusiveMinimum && n == *schema.Minimum)) {
+			v.addf("%s 不能小于 %v", at, *schema.Minimum)
+		}
+		if schema.Maximum != nil && (n > *schema.Maximum || (schema.ExclusiveMaximum && n == *schema.Maximum)) {
+			v.addf("%s 不能大于 %v", at, *schema.Maximum)
+		}
+		v.validateEnum(schema, number.String(), at)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			v.addf("%s 应为布尔值", at)
+		}
+	}
+}
+
+// validateFormat 校验字符串格式，空字符串不校验
+func (v *validator) validateFormat(format, value, at string) {
+	if value == "" {
+		return
+	}
+	var err error
+	switch format {
+	case "date-time":
+		_, err = time.Parse(time.RFC3339, value)
+	case "email":
+		_, err = mail.ParseAddress(value)
+	case "uri":
+		_, err = url.ParseRequestURI(value)
+	default:
+		return
+	}
+	if err != nil {
+		v.addf("%s 格式应为 %s", at, format)
+	}
+}
+
+// validateEnum 校验可选值
+func (v *validator) validateEnum(schema *Schema, value, at string) {
+	if len(schema.Enum) == 0 {
+		return
+	}
+	options := make([]string, len(schema.Enum))
+	for i, option := range schema.Enum {
+		options[i] = fmt.Sprint(option)
+		if options[i] == value {
+			return
+		}
+	}
+	v.addf("%s 应为 %s 之一", at, strings.Join(options, "、"))
+}
+
+func (v *validator) addf(format string, args ...interface{}) {
+	v.errors = append(v.errors, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,18 @@
+// Package Policies 授权策略
+// 功能说明：
+// 1. 每个策略对应一个模型或一类操作，方法返回用户是否可以执行对应操作
+// 2. 控制器在调用服务前检查策略，路由级别的角色限制仍由 PermissionMiddleware 负责
+// 3. 新策略使用 cloudctl make:policy 生成
+package Policies
+
+import "cloud-platform-api/app/Models"
+
+// IsAdmin 用户是否为启用状态的管理员
+func IsAdmin(user *Models.User) bool {
+	return user != nil && user.Role == "admin" && user.Status == 1
+}
+
+// IsOwner 用户是否为资源所有者
+func IsOwner(user *Models.User, ownerID uint) bool {
+	return user != nil && user.ID != 0 && user.ID == ownerID
+}
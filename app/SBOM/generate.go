@@ -0,0 +1,145 @@
+package SBOM
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// licenseFiles 模块根目录中的许可证文件名
+var licenseFiles = []string{"LICENSE", "LICENSE.md", "LICENSE.txt", "LICENCE", "LICENCE.md", "COPYING", "License", "license"}
+
+// Generate 生成软件物料清单
+// 功能说明：
+// 1. 在 dir（主模块内的任意目录）执行 go list -deps，只收集 packages 实际依赖的模块，不包括测试依赖
+// 2. packages 为空时使用主模块根目录的程序和 cmd 下的命令行工具
+// 3. 读取每个模块根目录的许可证文件并识别 SPDX 标识，无法识别时为 UNKNOWN
+// 4. 需要模块已下载到模块缓存（go mod download）
+func Generate(ctx context.Context, dir string, packages ...string) (*Document, error) {
+	if len(packages) == 0 {
+		cmd := exec.CommandContext(ctx, "go", "list", "-m")
+		cmd.Dir = dir
+		output, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("获取主模块失败: %v", err)
+		}
+		module := strings.TrimSpace(string(output))
+		packages = []string{module, module + "/cmd/..."}
+	}
+	args := append([]string{"list", "-deps", "-f",
+		"{{with .Module}}{{.Path}}\t{{.Version}}\t{{.Dir}}\t{{.Main}}{{with .Replace}}\t{{.Path}}\t{{.Version}}\t{{.Dir}}{{end}}{{end}}"}, packages...)
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list 执行失败: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	doc := &Document{GeneratedAt: &now, GoVersion: goVersion(ctx, dir), Source: "embedded"}
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 4 {
+			continue
+		}
+		if fields[3] == "true" {
+			doc.Name = fields[0]
+			continue
+		}
+		component := Component{Path: fields[0], Version: fields[1]}
+		moduleDir := fields[2]
+		if len(fields) >= 7 {
+			component.Replace = fields[0]
+			component.Path, component.Version, moduleDir = fields[4], fields[5], fields[6]
+		}
+		if seen[component.Path] {
+			continue
+		}
+		seen[component.Path] = true
+		component.License = DetectModuleLicense(moduleDir)
+		doc.Components = append(doc.Components, component)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	summarize(doc)
+	return doc, nil
+}
+
+// goVersion 返回生成清单使用的Go版本
+func goVersion(ctx context.Context, dir string) string {
+	cmd := exec.CommandContext(ctx, "go", "env", "GOVERSION")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// DetectModuleLicense 识别模块根目录中许可证文件的 SPDX 标识
+func DetectModuleLicense(dir string) string {
+	if dir == "" {
+		return UnknownLicense
+	}
+	for _, name := range licenseFiles {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return DetectLicense(string(data))
+		}
+	}
+	return UnknownLicense
+}
+
+// DetectLicense 根据许可证正文的特征识别常见许可证的 SPDX 标识
+func DetectLicense(text string) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(text)), " ")
+	contains := func(values ...string) bool {
+		for _, value := range values {
+			if !strings.Contains(normalized, value) {
+				return false
+			}
+		}
+		return true
+	}
+	switch {
+	case contains("apache license", "version 2.0"):
+		return "Apache-2.0"
+	case contains("mozilla public license", "2.0"):
+		return "MPL-2.0"
+	case contains("gnu lesser general public license", "version 3"):
+		return "LGPL-3.0"
+	case contains("gnu lesser general public license"):
+		return "LGPL-2.1"
+	case contains("gnu affero general public license"):
+		return "AGPL-3.0"
+	case contains("gnu general public license", "version 3"):
+		return "GPL-3.0"
+	case contains("gnu general public license"):
+		return "GPL-2.0"
+	case contains("permission is hereby granted, free of charge"):
+		return "MIT"
+	case contains("permission to use, copy, modify, and/or distribute this software for any purpose"),
+		contains("permission to use, copy, modify, and distribute this software for any purpose with or without fee"):
+		return "ISC"
+	case contains("redistribution and use in source and binary forms", "neither the name"),
+		contains("redistribution and use in source and binary forms", "names of its contributors may be used"):
+		return "BSD-3-Clause"
+	case contains("redistribution and use in source and binary forms"):
+		return "BSD-2-Clause"
+	case contains("this is free and unencumbered software released into the public domain"):
+		return "Unlicense"
+	default:
+		return UnknownLicense
+	}
+}
@@ -0,0 +1,168 @@
+package SBOM
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:generate go run ../../cmd/cloudctl sbom generate --output sbom.json
+
+// embeddedSBOM 构建前由 make sbom（cloudctl sbom generate）生成的依赖清单
+//
+//go:embed sbom.json
+var embeddedSBOM []byte
+
+// UnknownLicense 无法识别许可证时的标识
+const UnknownLicense = "UNKNOWN"
+
+// Document 软件物料清单
+// 功能说明：
+// 1. 模块和版本取自二进制的构建信息，与实际运行的代码一致
+// 2. 许可证取自构建时生成并内嵌的 sbom.json，构建信息中没有的模块（如未生成清单）为 UNKNOWN
+// 3. Licenses 为按许可证统计的模块数量，用于许可证合规检查
+type Document struct {
+	Name        string         `json:"name"`
+	Version     string         `json:"version"`
+	GoVersion   string         `json:"go_version"`
+	GeneratedAt *time.Time     `json:"generated_at,omitempty"` // 内嵌清单的生成时间
+	Source      string         `json:"source"`                 // build_info 或 embedded
+	Components  []Component    `json:"components"`
+	Licenses    map[string]int `json:"licenses"`
+}
+
+// Component 依赖模块
+type Component struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	License string `json:"license"`
+	Replace string `json:"replace,omitempty"` // replace 指令的原模块路径
+}
+
+// embeddedDocument 内嵌清单的文件格式
+type embeddedDocument struct {
+	Name        string      `json:"name"`
+	GoVersion   string      `json:"go_version"`
+	GeneratedAt *time.Time  `json:"generated_at,omitempty"`
+	Components  []Component `json:"components"`
+}
+
+var (
+	loadOnce sync.Once
+	loaded   *Document
+	loadErr  error
+)
+
+// Load 返回当前二进制的软件物料清单，结果在进程内缓存
+func Load() (*Document, error) {
+	loadOnce.Do(func() {
+		info, _ := debug.ReadBuildInfo()
+		loaded, loadErr = Parse(embeddedSBOM, info)
+	})
+	return loaded, loadErr
+}
+
+// Parse 合并内嵌清单和构建信息，info 为空或没有依赖时只使用内嵌清单
+func Parse(data []byte, info *debug.BuildInfo) (*Document, error) {
+	var embedded embeddedDocument
+	if len(strings.TrimSpace(string(data))) > 0 {
+		if err := json.Unmarshal(data, &embedded); err != nil {
+			return nil, fmt.Errorf("解析内嵌SBOM失败: %w", err)
+		}
+	}
+	licenses := make(map[string]string, len(embedded.Components))
+	for _, component := range embedded.Components {
+		licenses[component.Path] = component.License
+	}
+
+	doc := &Document{
+		Name:        embedded.Name,
+		GoVersion:   embedded.GoVersion,
+		GeneratedAt: embedded.GeneratedAt,
+		Source:      "embedded",
+		Components:  embedded.Components,
+	}
+	if info != nil && len(info.Deps) > 0 {
+		doc.Source = "build_info"
+		doc.GoVersion = info.GoVersion
+		if info.Main.Path != "" {
+			doc.Name = info.Main.Path
+		}
+		doc.Version = info.Main.Version
+		doc.Components = make([]Component, 0, len(info.Deps))
+		for _, dep := range info.Deps {
+			component := Component{Path: dep.Path, Version: dep.Version}
+			if dep.Replace != nil {
+				component.Replace = dep.Path
+				component.Path = dep.Replace.Path
+				component.Version = dep.Replace.Version
+			}
+			component.License = licenses[component.Path]
+			doc.Components = append(doc.Components, component)
+		}
+	}
+
+	summarize(doc)
+	return doc, nil
+}
+
+// summarize 按模块路径排序并统计许可证，没有许可证的模块标记为 UNKNOWN
+func summarize(doc *Document) {
+	sort.Slice(doc.Components, func(i, j int) bool { return doc.Components[i].Path < doc.Components[j].Path })
+	doc.Licenses = make(map[string]int)
+	for i := range doc.Components {
+		if doc.Components[i].License == "" {
+			doc.Components[i].License = UnknownLicense
+		}
+		doc.Licenses[doc.Components[i].License]++
+	}
+}
+
+// Marshal 序列化为内嵌清单的文件格式
+func Marshal(doc *Document) ([]byte, error) {
+	data, err := json.MarshalIndent(embeddedDocument{
+		Name:        doc.Name,
+		GoVersion:   doc.GoVersion,
+		GeneratedAt: doc.GeneratedAt,
+		Components:  doc.Components,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// CycloneDX 转换为 CycloneDX 1.5 JSON 格式，供外部SBOM工具导入
+func (d *Document) CycloneDX() map[string]interface{} {
+	components := make([]map[string]interface{}, 0, len(d.Components))
+	for _, c := range d.Components {
+		component := map[string]interface{}{
+			"type":    "library",
+			"name":    c.Path,
+			"version": c.Version,
+			"purl":    "pkg:golang/" + c.Path + "@" + c.Version,
+		}
+		if c.License != UnknownLicense {
+			component["licenses"] = []map[string]interface{}{{"license": map[string]string{"id": c.License}}}
+		}
+		components = append(components, component)
+	}
+	metadata := map[string]interface{}{
+		"component": map[string]string{"type": "application", "name": d.Name, "version": d.Version},
+	}
+	if d.GeneratedAt != nil {
+		metadata["timestamp"] = d.GeneratedAt.UTC().Format(time.RFC3339)
+	}
+	return map[string]interface{}{
+		"bomFormat":   "CycloneDX",
+		"specVersion": "1.5",
+		"version":     1,
+		"metadata":    metadata,
+		"components":  components,
+	}
+}
@@ -0,0 +1,146 @@
+package Services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clfTimeLayout 通用日志格式的时间格式，如 10/Oct/2000:13:55:36 -0700
+const clfTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// CombinedFormatter Apache/NGINX combined 和通用日志格式（CLF）
+//
+// 功能说明：
+// 1. 输出 `host - user [time] "request" status bytes "referer" "user-agent"`，Common 为 true 时不输出 Referer 和 User-Agent
+// 2. 字段取自请求日志和访问日志记录的字段，缺少的字段按惯例输出 -
+// 3. 时间为请求开始时间（start_time），没有时使用日志时间；响应字节数为0时输出 -
+// 4. 引号、反斜杠和控制字符按Apache的规则转义，请求行中的换行等字符不会破坏一行一条的格式
+type CombinedFormatter struct {
+	Common bool
+}
+
+// Format 格式化日志条目
+func (f *CombinedFormatter) Format(entry LogEntry) ([]byte, error) {
+	fields := entry.Fields
+
+	host := fieldString(fields, "client_ip", "ip")
+	if host == "" {
+		host = entry.IP
+	}
+	user := fieldString(fields, "username", "user_id")
+
+	requestTime := entry.Timestamp
+	if start := fieldString(fields, "start_time"); start != "" {
+		if parsed, err := time.Parse(time.RFC3339Nano, start); err == nil {
+			requestTime = parsed
+		}
+	}
+
+	uri := fieldString(fields, "uri")
+	if uri == "" {
+		uri = fieldString(fields, "path")
+		if query := fieldString(fields, "query"); query != "" {
+			uri += "?" + query
+		}
+	}
+	request := strings.TrimSpace(strings.Join([]string{fieldString(fields, "method"), uri, fieldString(fields, "protocol")}, " "))
+
+	status := fieldString(fields, "status_code")
+	bytes := fieldString(fields, "response_size")
+	if n, err := strconv.ParseInt(bytes, 10, 64); err != nil || n <= 0 {
+		bytes = "-"
+	}
+
+	var b strings.Builder
+	b.Grow(256)
+	b.WriteString(orDash(escapeCLF(host)))
+	b.WriteString(" - ")
+	b.WriteString(orDash(escapeCLF(user)))
+	b.WriteString(" [")
+	b.WriteString(requestTime.Format(clfTimeLayout))
+	b.WriteString(`] "`)
+	b.WriteString(orDash(escapeCLF(request)))
+	b.WriteString(`" `)
+	b.WriteString(orDash(status))
+	b.WriteByte(' ')
+	b.WriteString(bytes)
+	if !f.Common {
+		userAgent := fieldString(fields, "user_agent")
+		if userAgent == "" {
+			userAgent = entry.UserAgent
+		}
+		b.WriteString(` "`)
+		b.WriteString(orDash(escapeCLF(fieldString(fields, "referer"))))
+		b.WriteString(`" "`)
+		b.WriteString(orDash(escapeCLF(userAgent)))
+		b.WriteByte('"')
+	}
+	return []byte(b.String()), nil
+}
+
+// fieldString 按顺序取第一个非空字段并转换为字符串
+func fieldString(fields map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		value, ok := fields[key]
+		if !ok || value == nil {
+			continue
+		}
+		var s string
+		switch v := value.(type) {
+		case string:
+			s = v
+		case fmt.Stringer:
+			s = v.String()
+		default:
+			s = fmt.Sprint(v)
+		}
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// orDash 空值输出 -
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// escapeCLF 转义引号、反斜杠和控制字符，与Apache mod_log_config 的规则一致
+func escapeCLF(value string) string {
+	needsEscape := false
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; c == '"' || c == '\\' || c < 0x20 || c == 0x7f {
+			needsEscape = true
+			break
+		}
+	}
+	if !needsEscape {
+		return value
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\r':
+			b.WriteString(`\r`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&b, `\x%02x`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
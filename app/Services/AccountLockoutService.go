@@ -0,0 +1,424 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Utils"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 自助解锁时记录的解锁原因
+const SelfServiceUnlockReason = "self_service"
+
+var (
+	// ErrLockoutNotActive 锁定记录已解除或已过期
+	ErrLockoutNotActive = errors.New("锁定记录已解除或已过期")
+	// ErrInvalidUnlockToken 解锁链接无效
+	ErrInvalidUnlockToken = errors.New("解锁链接无效")
+	// ErrUnlockTokenExpired 解锁链接已过期
+	ErrUnlockTokenExpired = errors.New("解锁链接已过期")
+)
+
+// NotificationMailer 发送通知邮件的接口，EmailService 实现了该接口
+type NotificationMailer interface {
+	SendNotificationEmail(to, subject, body string) error
+}
+
+// defaultNotificationMailer 优先使用全局邮件服务，其次在全局邮件配置可用时返回 EmailService，否则返回 nil
+func defaultNotificationMailer() NotificationMailer {
+	if mailService := DefaultMailService(); mailService != nil {
+		return mailService
+	}
+	globalConfig := Config.GetConfig()
+	if globalConfig == nil || !globalConfig.Email.IsConfigured() {
+		return nil
+	}
+	return NewEmailService(&EmailConfig{
+		Host:     globalConfig.Email.Host,
+		Port:     globalConfig.Email.Port,
+		Username: globalConfig.Email.Username,
+		Password: globalConfig.Email.Password,
+		From:     globalConfig.Email.From,
+		UseTLS:   globalConfig.Email.UseTLS,
+	})
+}
+
+// AccountLockoutFilter 锁定记录查询条件
+type AccountLockoutFilter struct {
+	Username    string // 用户名，模糊匹配
+	IPAddress   string // IP地址，精确匹配
+	LockoutType string // 锁定类型
+	ActiveOnly  bool   // 只查询生效中的锁定
+	Page        int
+	Limit       int
+}
+
+// AccountLockoutService 账户锁定管理服务
+// 功能说明：
+// 1. 按用户名、IP、锁定类型查询锁定记录，管理员可按记录、用户名或IP解锁
+// 2. 账户被锁定时向用户邮箱发送带HMAC签名的解锁链接，用户可自助解锁
+// 3. 统计指定时间段内的锁定数量、类型分布、解锁方式和锁定最多的用户/IP，用于安全报告
+type AccountLockoutService struct {
+	db     *gorm.DB
+	config *Config.AccountUnlockConfig
+	mailer NotificationMailer
+}
+
+// NewAccountLockoutService 创建账户锁定管理服务
+//
+// config 为 nil 时使用全局配置；mailer 为 nil 且全局邮件服务已配置时使用 EmailService。
+func NewAccountLockoutService(db *gorm.DB, config *Config.AccountUnlockConfig, mailer NotificationMailer) *AccountLockoutService {
+	if config == nil {
+		if globalConfig := Config.GetConfig(); globalConfig != nil {
+			config = &globalConfig.Security.AccountUnlock
+		} else {
+			securityConfig := &Config.SecurityConfig{}
+			securityConfig.SetDefaults()
+			config = &securityConfig.AccountUnlock
+		}
+	}
+	if mailer == nil {
+		mailer = defaultNotificationMailer()
+	}
+
+	return &AccountLockoutService{
+		db:     db,
+		config: config,
+		mailer: mailer,
+	}
+}
+
+// List 查询锁定记录
+func (s *AccountLockoutService) List(filter AccountLockoutFilter) ([]Models.AccountLockout, int64, error) {
+	query := s.filterQuery(filter)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 20
+	}
+	var lockouts []Models.AccountLockout
+	err := query.Order("lockout_time DESC").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&lockouts).Error
+	return lockouts, total, err
+}
+
+// ListPage 按分页请求查询锁定记录，支持页码分页和游标分页，filter 中的分页字段不生效
+func (s *AccountLockoutService) ListPage(filter AccountLockoutFilter, req Utils.PageRequest) ([]Models.AccountLockout, Utils.PageMeta, error) {
+	var lockouts []Models.AccountLockout
+	meta, err := Utils.Paginate(s.filterQuery(filter), req, Utils.PageOrder{Column: "lockout_time"}, &lockouts)
+	return lockouts, meta, err
+}
+
+// filterQuery 按筛选条件构建查询
+func (s *AccountLockoutService) filterQuery(filter AccountLockoutFilter) *gorm.DB {
+	query := s.db.Model(&Models.AccountLockout{})
+	if filter.Username != "" {
+		query = query.Where("username LIKE ?", "%"+filter.Username+"%")
+	}
+	if filter.IPAddress != "" {
+		query = query.Where("ip_address = ?", filter.IPAddress)
+	}
+	if filter.LockoutType != "" {
+		query = query.Where("lockout_type = ?", filter.LockoutType)
+	}
+	if filter.ActiveOnly {
+		query = query.Where("active = ? AND expiry_time > ?", true, time.Now())
+	}
+	return query
+}
+
+// Unlock 解除指定的锁定
+func (s *AccountLockoutService) Unlock(id, unlockedBy uint, reason string) (*Models.AccountLockout, error) {
+	var lockout Models.AccountLockout
+	if err := s.db.First(&lockout, id).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := s.db.Model(&Models.AccountLockout{}).
+		Where("id = ? AND active = ? AND expiry_time > ?", id, true, now).
+		Updates(map[string]interface{}{
+			"active":        false,
+			"unlocked_by":   unlockedBy,
+			"unlock_time":   now,
+			"unlock_reason": reason,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return &lockout, ErrLockoutNotActive
+	}
+	InvalidateIPBlockCache()
+
+	lockout.Active = false
+	lockout.UnlockedBy = &unlockedBy
+	lockout.UnlockTime = &now
+	lockout.UnlockReason = reason
+	return &lockout, nil
+}
+
+// UnlockBy 按用户名和/或IP解除所有生效中的锁定，返回解除的数量
+func (s *AccountLockoutService) UnlockBy(username, ipAddress string, unlockedBy uint, reason string) (int64, error) {
+	if username == "" && ipAddress == "" {
+		return 0, fmt.Errorf("用户名和IP地址不能同时为空")
+	}
+
+	now := time.Now()
+	query := s.db.Model(&Models.AccountLockout{}).Where("active = ? AND expiry_time > ?", true, now)
+	if username != "" {
+		query = query.Where("username = ?", username)
+	}
+	if ipAddress != "" {
+		query = query.Where("ip_address = ?", ipAddress)
+	}
+
+	result := query.Updates(map[string]interface{}{
+		"active":        false,
+		"unlocked_by":   unlockedBy,
+		"unlock_time":   now,
+		"unlock_reason": reason,
+	})
+	if result.RowsAffected > 0 {
+		InvalidateIPBlockCache()
+	}
+	return result.RowsAffected, result.Error
+}
+
+// LocksAdmin 解锁范围内是否有管理员账户的生效锁定
+//
+// id 不为0时只检查该锁定记录，否则按用户名和/或IP检查所有生效中的锁定，锁定记录按用户ID或用户名关联用户。
+func (s *AccountLockoutService) LocksAdmin(id uint, username, ipAddress string) (bool, error) {
+	query := s.db.Model(&Models.AccountLockout{}).Where("active = ? AND expiry_time > ?", true, time.Now())
+	if id != 0 {
+		query = query.Where("id = ?", id)
+	} else {
+		if username == "" && ipAddress == "" {
+			return false, nil
+		}
+		if username != "" {
+			query = query.Where("username = ?", username)
+		}
+		if ipAddress != "" {
+			query = query.Where("ip_address = ?", ipAddress)
+		}
+	}
+	adminIDs := s.db.Model(&Models.User{}).Select("id").Where("role = ?", "admin")
+	adminNames := s.db.Model(&Models.User{}).Select("username").Where("role = ?", "admin")
+	var count int64
+	err := query.Where("user_id IN (?) OR username IN (?)", adminIDs, adminNames).Count(&count).Error
+	return count > 0, err
+}
+
+// GenerateUnlockToken 生成锁定记录的解锁令牌
+//
+// 令牌格式为 base64url(锁定ID.过期时间戳).签名，签名覆盖锁定ID、过期时间和用户名。
+func (s *AccountLockoutService) GenerateUnlockToken(lockout *Models.AccountLockout) string {
+	expiresAt := time.Now().Add(s.config.LinkTTL).Unix()
+	payload := fmt.Sprintf("%d.%d", lockout.ID, expiresAt)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + s.sign(payload, lockout.Username)
+}
+
+// UnlockURL 生成自助解锁链接
+func (s *AccountLockoutService) UnlockURL(lockout *Models.AccountLockout) string {
+	return strings.TrimRight(s.config.BaseURL, "/") + "/api/v1/security/unlock?token=" + url.QueryEscape(s.GenerateUnlockToken(lockout))
+}
+
+// SelfServiceUnlock 校验解锁令牌并解除锁定，令牌只能使用一次
+func (s *AccountLockoutService) SelfServiceUnlock(token string) (*Models.AccountLockout, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidUnlockToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidUnlockToken
+	}
+	idPart, expiresPart, ok := strings.Cut(string(payload), ".")
+	if !ok {
+		return nil, ErrInvalidUnlockToken
+	}
+	id, err := strconv.ParseUint(idPart, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidUnlockToken
+	}
+	expiresAt, err := strconv.ParseInt(expiresPart, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidUnlockToken
+	}
+
+	var lockout Models.AccountLockout
+	if err := s.db.First(&lockout, id).Error; err != nil {
+		return nil, ErrInvalidUnlockToken
+	}
+	expected := s.sign(string(payload), lockout.Username)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return nil, ErrInvalidUnlockToken
+	}
+	if time.Now().Unix() > expiresAt {
+		return nil, ErrUnlockTokenExpired
+	}
+
+	return s.Unlock(lockout.ID, lockout.UserID, SelfServiceUnlockReason)
+}
+
+// sign 计算解锁令牌签名
+func (s *AccountLockoutService) sign(payload, username string) string {
+	mac := hmac.New(sha256.New, []byte(s.secret()))
+	mac.Write([]byte(payload))
+	mac.Write([]byte{0})
+	mac.Write([]byte(username))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// secret 签名密钥，未配置时使用JWT密钥
+func (s *AccountLockoutService) secret() string {
+	if s.config.Secret != "" {
+		return s.config.Secret
+	}
+	if globalConfig := Config.GetConfig(); globalConfig != nil {
+		if globalConfig.JWT.SecretKey != "" {
+			return globalConfig.JWT.SecretKey
+		}
+		return globalConfig.JWT.Secret
+	}
+	return ""
+}
+
+// NotifyLockout 向被锁定的用户发送解锁邮件
+//
+// 未启用解锁邮件、邮件服务未配置、签名密钥为空或找不到用户时不发送。
+func (s *AccountLockoutService) NotifyLockout(lockout *Models.AccountLockout) error {
+	if !s.config.EmailEnabled || s.mailer == nil || s.secret() == "" {
+		return nil
+	}
+
+	var user Models.User
+	query := s.db.Select("id", "username", "email")
+	if lockout.UserID != 0 {
+		query = query.Where("id = ?", lockout.UserID)
+	} else {
+		query = query.Where("username = ?", lockout.Username)
+	}
+	if err := query.First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.Email == "" {
+		return nil
+	}
+
+	unlockURL := s.UnlockURL(lockout)
+	body := fmt.Sprintf(`
+		<h2>账户已被锁定</h2>
+		<p>您好 %s，</p>
+		<p>由于%s，您的账户已于 %s 被临时锁定，将在 %s 自动解锁。</p>
+		<p>如果是您本人操作，可以点击以下链接立即解锁：</p>
+		<p><a href="%s">解锁账户</a></p>
+		<p>此链接将在%s后过期。如果不是您本人操作，请忽略此邮件并尽快修改密码。</p>
+	`, html.EscapeString(user.Username), html.EscapeString(lockout.Reason),
+		lockout.LockoutTime.Format("2006-01-02 15:04:05"), lockout.ExpiryTime.Format("2006-01-02 15:04:05"),
+		html.EscapeString(unlockURL), s.config.LinkTTL)
+
+	return s.mailer.SendNotificationEmail(user.Email, "账户锁定通知", body)
+}
+
+// Statistics 统计时间段内的锁定情况
+// 功能说明：
+// 1. 汇总锁定总数、生效中的锁定、管理员解锁与自助解锁次数
+// 2. 按锁定类型分组，并列出锁定次数最多的用户名和IP
+// 3. 任一查询失败时返回错误，不返回不完整的统计
+func (s *AccountLockoutService) Statistics(ctx context.Context, startDate, endDate time.Time) (map[string]interface{}, error) {
+	base := func() *gorm.DB {
+		return s.db.WithContext(ctx).Model(&Models.AccountLockout{}).Where("lockout_time BETWEEN ? AND ?", startDate, endDate)
+	}
+
+	var total, active, manualUnlocks, selfServiceUnlocks int64
+	if err := base().Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("统计锁定总数失败: %w", err)
+	}
+	if err := base().Where("active = ? AND expiry_time > ?", true, time.Now()).Count(&active).Error; err != nil {
+		return nil, fmt.Errorf("统计生效中的锁定失败: %w", err)
+	}
+	if err := base().Where("unlock_time IS NOT NULL AND unlock_reason <> ?", SelfServiceUnlockReason).Count(&manualUnlocks).Error; err != nil {
+		return nil, fmt.Errorf("统计管理员解锁失败: %w", err)
+	}
+	if err := base().Where("unlock_reason = ?", SelfServiceUnlockReason).Count(&selfServiceUnlocks).Error; err != nil {
+		return nil, fmt.Errorf("统计自助解锁失败: %w", err)
+	}
+
+	// 别名避开 key 等数据库保留字（MySQL中 KEY 为保留字）
+	type groupCount struct {
+		GroupKey   string
+		GroupCount int64
+	}
+	topBy := func(column string) ([]map[string]interface{}, error) {
+		var rows []groupCount
+		err := base().Select(column + " AS group_key, COUNT(*) AS group_count").
+			Where(column + " <> ''").
+			Group(column).
+			Order("group_count DESC").
+			Limit(10).
+			Scan(&rows).Error
+		if err != nil {
+			return nil, fmt.Errorf("按%s统计锁定失败: %w", column, err)
+		}
+		result := make([]map[string]interface{}, 0, len(rows))
+		for _, row := range rows {
+			result = append(result, map[string]interface{}{column: row.GroupKey, "count": row.GroupCount})
+		}
+		return result, nil
+	}
+
+	var typeRows []groupCount
+	if err := base().Select("lockout_type AS group_key, COUNT(*) AS group_count").Group("lockout_type").Scan(&typeRows).Error; err != nil {
+		return nil, fmt.Errorf("按锁定类型统计失败: %w", err)
+	}
+	byType := make(map[string]int64, len(typeRows))
+	for _, row := range typeRows {
+		byType[row.GroupKey] = row.GroupCount
+	}
+
+	topUsernames, err := topBy("username")
+	if err != nil {
+		return nil, err
+	}
+	topIPs, err := topBy("ip_address")
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"total_lockouts":       total,
+		"active_lockouts":      active,
+		"manual_unlocks":       manualUnlocks,
+		"self_service_unlocks": selfServiceUnlocks,
+		"by_type":              byType,
+		"top_usernames":        topUsernames,
+		"top_ip_addresses":     topIPs,
+	}, nil
+}
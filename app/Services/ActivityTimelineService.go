@@ -0,0 +1,301 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Utils"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 用户活动类型
+const (
+	ActivityTypeLogin    = "login"    // 登录尝试
+	ActivityTypePassword = "password" // 修改密码、重置密码
+	ActivityTypeAPIKey   = "api_key"  // 创建、更新、删除、重新生成API密钥
+	ActivityTypeProfile  = "profile"  // 修改资料、验证邮箱
+	ActivityTypeSecurity = "security" // 安全事件、管理员模拟登录
+)
+
+// ActivityTypes 所有用户活动类型
+var ActivityTypes = []string{ActivityTypeLogin, ActivityTypePassword, ActivityTypeAPIKey, ActivityTypeProfile, ActivityTypeSecurity}
+
+// ErrInvalidActivityType 未知的活动类型
+var ErrInvalidActivityType = errors.New("未知的活动类型")
+
+// 审计日志中属于各活动类型的操作
+var (
+	passwordActivityActions = []string{"password_change", "password_reset", "password_reset_request"}
+	profileActivityActions  = []string{"update_user", "email_verification"}
+	securityActivityActions = []string{Models.AuditActionImpersonationStart, Models.AuditActionImpersonationEnd}
+)
+
+// ActivityEvent 用户活动时间线中的一条记录
+type ActivityEvent struct {
+	ID          string    `json:"id"`   // 活动类型和来源记录ID，如 login-12
+	Type        string    `json:"type"` // 活动类型
+	Action      string    `json:"action"`
+	Description string    `json:"description"`
+	Time        time.Time `json:"time"`
+	IPAddress   string    `json:"ip_address,omitempty"`
+	Location    string    `json:"location,omitempty"`
+	Device      string    `json:"device,omitempty"` // 浏览器和操作系统，如 Chrome on Windows
+	Success     bool      `json:"success"`
+	Level       string    `json:"level,omitempty"` // 审计日志和安全事件的级别
+}
+
+// ActivityFilter 时间线查询条件
+type ActivityFilter struct {
+	Types []string   // 活动类型，为空时返回全部类型
+	Since *time.Time // 开始时间（包含）
+	Until *time.Time // 结束时间（包含）
+}
+
+// activitySource 时间线的一个数据来源
+type activitySource struct {
+	Type       string
+	TimeColumn string
+	Query      func(user *Models.User) *gorm.DB              // 该用户在此来源中的记录
+	Load       func(query *gorm.DB) ([]ActivityEvent, error) // 查询并转换为活动记录
+}
+
+// ActivityTimelineService 用户活动时间线服务
+// 功能说明：
+// 1. 从登录尝试、审计日志和安全事件中汇总用户的登录、密码修改、API密钥操作、资料修改和安全事件，按时间倒序合并
+// 2. 支持按活动类型和时间范围筛选，支持页码分页和游标分页
+// 3. 每个来源只查询当前页需要的记录数：页码分页取前 page*limit+1 条，游标分页取游标之前的 limit+1 条
+type ActivityTimelineService struct {
+	db      *gorm.DB
+	sources []activitySource
+}
+
+// NewActivityTimelineService 创建用户活动时间线服务
+func NewActivityTimelineService(db *gorm.DB) *ActivityTimelineService {
+	s := &ActivityTimelineService{db: db}
+	auditActions := func(actions []string) func(user *Models.User) *gorm.DB {
+		return func(user *Models.User) *gorm.DB {
+			return s.db.Model(&Models.AuditLog{}).
+				Where("resource = ? AND resource_id = ? AND action IN ?", "user", user.ID, actions)
+		}
+	}
+	s.sources = []activitySource{
+		{
+			Type:       ActivityTypeLogin,
+			TimeColumn: "attempt_time",
+			Query: func(user *Models.User) *gorm.DB {
+				return s.db.Model(&Models.LoginAttempt{}).Where("username = ?", user.Username)
+			},
+			Load: loadLoginActivities,
+		},
+		{Type: ActivityTypePassword, TimeColumn: "created_at", Query: auditActions(passwordActivityActions), Load: auditActivityLoader(ActivityTypePassword)},
+		{
+			Type:       ActivityTypeAPIKey,
+			TimeColumn: "created_at",
+			Query: func(user *Models.User) *gorm.DB {
+				return s.db.Model(&Models.AuditLog{}).Where("user_id = ? AND resource = ?", user.ID, "api_key")
+			},
+			Load: auditActivityLoader(ActivityTypeAPIKey),
+		},
+		{Type: ActivityTypeProfile, TimeColumn: "created_at", Query: auditActions(profileActivityActions), Load: auditActivityLoader(ActivityTypeProfile)},
+		{
+			Type:       ActivityTypeSecurity,
+			TimeColumn: "created_at",
+			Query: func(user *Models.User) *gorm.DB {
+				return s.db.Model(&Models.SecurityEvent{}).Where("user_id = ?", user.ID)
+			},
+			Load: loadSecurityActivities,
+		},
+		{Type: ActivityTypeSecurity, TimeColumn: "created_at", Query: auditActions(securityActivityActions), Load: auditActivityLoader(ActivityTypeSecurity)},
+	}
+	return s
+}
+
+// ParseActivityTypes 校验活动类型，为空时返回全部类型
+func ParseActivityTypes(types []string) ([]string, error) {
+	if len(types) == 0 {
+		return ActivityTypes, nil
+	}
+	for _, t := range types {
+		if !containsString(ActivityTypes, t) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidActivityType, t)
+		}
+	}
+	return types, nil
+}
+
+// Timeline 分页查询用户的活动时间线，按时间倒序
+func (s *ActivityTimelineService) Timeline(userID uint, filter ActivityFilter, req Utils.PageRequest) ([]ActivityEvent, Utils.PageMeta, error) {
+	types, err := ParseActivityTypes(filter.Types)
+	if err != nil {
+		return nil, Utils.PageMeta{}, err
+	}
+	var user Models.User
+	if err := s.db.Select("id", "username").First(&user, userID).Error; err != nil {
+		return nil, Utils.PageMeta{}, err
+	}
+
+	limit := max(req.Limit, 1)
+	var cursorTime *time.Time
+	if req.Mode == Utils.PageModeCursor && req.Cursor != "" {
+		if cursorTime, _, err = Utils.DecodeCursor(req.Cursor); err != nil || cursorTime == nil {
+			return nil, Utils.PageMeta{}, Utils.ErrInvalidCursor
+		}
+	}
+
+	var events []ActivityEvent
+	var total int64
+	for _, source := range s.sources {
+		if !containsString(types, source.Type) {
+			continue
+		}
+		scoped := func() *gorm.DB {
+			query := source.Query(&user)
+			if filter.Since != nil {
+				query = query.Where(source.TimeColumn+" >= ?", *filter.Since)
+			}
+			if filter.Until != nil {
+				query = query.Where(source.TimeColumn+" <= ?", *filter.Until)
+			}
+			return query
+		}
+		if req.WithTotal {
+			var count int64
+			if err := scoped().Count(&count).Error; err != nil {
+				return nil, Utils.PageMeta{}, err
+			}
+			total += count
+		}
+
+		order := source.TimeColumn + " DESC, id DESC"
+		if cursorTime == nil {
+			// 页码分页：合并后第 page 页及是否有下一页只取决于每个来源最新的 page*limit+1 条
+			loaded, err := source.Load(scoped().Order(order).Limit(max(req.Page, 1)*limit + 1))
+			if err != nil {
+				return nil, Utils.PageMeta{}, err
+			}
+			events = append(events, loaded...)
+			continue
+		}
+		// 游标分页：与游标同一时间的记录全部取出，由 PageSlice 按键跳过已返回的记录
+		atCursor, err := source.Load(scoped().Where(source.TimeColumn+" = ?", *cursorTime).Order(order))
+		if err != nil {
+			return nil, Utils.PageMeta{}, err
+		}
+		older, err := source.Load(scoped().Where(source.TimeColumn+" < ?", *cursorTime).Order(order).Limit(limit + 1))
+		if err != nil {
+			return nil, Utils.PageMeta{}, err
+		}
+		events = append(events, atCursor...)
+		events = append(events, older...)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].Time.Equal(events[j].Time) {
+			return events[i].Time.After(events[j].Time)
+		}
+		return events[i].ID > events[j].ID
+	})
+	pageReq := req
+	pageReq.WithTotal = false
+	start, end, meta, err := Utils.PageSlice(len(events), pageReq, func(i int) (time.Time, string) {
+		return events[i].Time, events[i].ID
+	})
+	if err != nil {
+		return nil, meta, err
+	}
+	if req.WithTotal {
+		totalPages := int((total + int64(meta.PageSize) - 1) / int64(meta.PageSize))
+		meta.Total = &total
+		meta.TotalPages = &totalPages
+	}
+	return events[start:end], meta, nil
+}
+
+// loadLoginActivities 查询登录尝试
+func loadLoginActivities(query *gorm.DB) ([]ActivityEvent, error) {
+	var attempts []Models.LoginAttempt
+	if err := query.Find(&attempts).Error; err != nil {
+		return nil, err
+	}
+	events := make([]ActivityEvent, 0, len(attempts))
+	for _, attempt := range attempts {
+		description := "登录成功"
+		if !attempt.Success {
+			description = "登录失败"
+			if attempt.FailureReason != "" {
+				description += ": " + attempt.FailureReason
+			}
+		}
+		events = append(events, ActivityEvent{
+			ID:          fmt.Sprintf("%s-%d", ActivityTypeLogin, attempt.ID),
+			Type:        ActivityTypeLogin,
+			Action:      "login",
+			Description: description,
+			Time:        attempt.AttemptTime,
+			IPAddress:   attempt.IPAddress,
+			Location:    attempt.Location,
+			Device:      attempt.DeviceInfo,
+			Success:     attempt.Success,
+		})
+	}
+	return events, nil
+}
+
+// auditActivityLoader 查询审计日志，转换为指定类型的活动记录
+func auditActivityLoader(activityType string) func(query *gorm.DB) ([]ActivityEvent, error) {
+	return func(query *gorm.DB) ([]ActivityEvent, error) {
+		var logs []Models.AuditLog
+		if err := query.Find(&logs).Error; err != nil {
+			return nil, err
+		}
+		events := make([]ActivityEvent, 0, len(logs))
+		for _, entry := range logs {
+			event := ActivityEvent{
+				ID:          fmt.Sprintf("%s-%d", activityType, entry.ID),
+				Type:        activityType,
+				Action:      entry.Action,
+				Description: entry.Description,
+				Time:        entry.CreatedAt,
+				IPAddress:   entry.IPAddress,
+				Success:     entry.Status != Models.AuditStatusFailed,
+				Level:       entry.Level,
+			}
+			if entry.UserAgent != "" {
+				event.Device = Utils.ParseUserAgent(entry.UserAgent).String()
+			}
+			events = append(events, event)
+		}
+		return events, nil
+	}
+}
+
+// loadSecurityActivities 查询安全事件
+func loadSecurityActivities(query *gorm.DB) ([]ActivityEvent, error) {
+	var securityEvents []Models.SecurityEvent
+	if err := query.Find(&securityEvents).Error; err != nil {
+		return nil, err
+	}
+	events := make([]ActivityEvent, 0, len(securityEvents))
+	for _, securityEvent := range securityEvents {
+		description := securityEvent.Details
+		if description == "" {
+			description = securityEvent.EventType
+		}
+		events = append(events, ActivityEvent{
+			ID:          fmt.Sprintf("%s-event-%d", ActivityTypeSecurity, securityEvent.ID),
+			Type:        ActivityTypeSecurity,
+			Action:      securityEvent.EventType,
+			Description: description,
+			Time:        securityEvent.CreatedAt,
+			IPAddress:   securityEvent.IPAddress,
+			Location:    securityEvent.Location,
+			Device:      securityEvent.DeviceInfo,
+			Success:     !securityEvent.Blocked,
+			Level:       securityEvent.EventLevel,
+		})
+	}
+	return events, nil
+}
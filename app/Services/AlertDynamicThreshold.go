@@ -0,0 +1,276 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Models"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+)
+
+// 告警规则类型
+const (
+	AlertRuleTypeStatic  = "static"  // 固定阈值
+	AlertRuleTypeDynamic = "dynamic" // 按历史数据计算的动态阈值
+)
+
+// 动态阈值的越界方向
+const (
+	DynamicDirectionAbove = "above" // 高于上界
+	DynamicDirectionBelow = "below" // 低于下界
+	DynamicDirectionBoth  = "both"  // 超出上下界
+)
+
+// ErrMetricHistoryUnavailable 未配置指标历史，无法计算动态阈值或回测
+var ErrMetricHistoryUnavailable = errors.New("指标历史未启用")
+
+// DynamicThreshold 动态阈值配置
+// 功能说明：
+// 1. 取过去 Periods 个周期（Seasonality）中对应时刻前后 Window 内的历史采样，计算均值和标准差
+// 2. 阈值为 均值 ± Sensitivity × 标准差，默认即“上周同一小时的3倍标准差”
+// 3. 历史采样少于 MinSamples 时无法计算阈值，规则不触发
+type DynamicThreshold struct {
+	Sensitivity float64       `json:"sensitivity"` // 标准差倍数，越小越敏感
+	Seasonality time.Duration `json:"seasonality"` // 基线周期，7天表示与上周同一时段比较
+	Periods     int           `json:"periods"`     // 参与计算的历史周期数
+	Window      time.Duration `json:"window"`      // 每个周期以对应时刻为中心的取样窗口
+	Direction   string        `json:"direction"`   // above、below 或 both
+	MinSamples  int           `json:"min_samples"` // 计算阈值所需的最少历史采样数
+}
+
+// withDefaults 补全未设置的参数
+func (d DynamicThreshold) withDefaults() DynamicThreshold {
+	if d.Sensitivity == 0 {
+		d.Sensitivity = 3
+	}
+	if d.Seasonality == 0 {
+		d.Seasonality = 7 * 24 * time.Hour
+	}
+	if d.Periods == 0 {
+		d.Periods = 1
+	}
+	if d.Window == 0 {
+		d.Window = time.Hour
+	}
+	if d.Direction == "" {
+		d.Direction = DynamicDirectionAbove
+	}
+	if d.MinSamples == 0 {
+		d.MinSamples = 10
+	}
+	return d
+}
+
+// validate 校验参数
+func (d DynamicThreshold) validate() error {
+	if d.Sensitivity <= 0 || d.Seasonality <= 0 || d.Periods <= 0 || d.Window <= 0 || d.MinSamples <= 0 {
+		return fmt.Errorf("动态阈值参数必须为正数")
+	}
+	if d.Window > d.Seasonality {
+		return fmt.Errorf("取样窗口不能大于基线周期")
+	}
+	switch d.Direction {
+	case DynamicDirectionAbove, DynamicDirectionBelow, DynamicDirectionBoth:
+		return nil
+	default:
+		return fmt.Errorf("无效的越界方向: %s", d.Direction)
+	}
+}
+
+// lookback 计算阈值需要的最早历史距当前时刻的时长
+func (d DynamicThreshold) lookback() time.Duration {
+	return time.Duration(d.Periods)*d.Seasonality + d.Window/2
+}
+
+// dynamicBounds 动态阈值计算结果
+type dynamicBounds struct {
+	mean    float64
+	stddev  float64
+	lower   float64
+	upper   float64
+	samples int
+}
+
+// computeDynamicBounds 根据按时间升序的历史采样计算 at 时刻的阈值
+func computeDynamicBounds(samples []Models.MetricSample, at time.Time, d DynamicThreshold) (dynamicBounds, bool) {
+	var n int
+	var sum, sumSquares float64
+	for period := 1; period <= d.Periods; period++ {
+		center := at.Add(-time.Duration(period) * d.Seasonality)
+		from, to := center.Add(-d.Window/2), center.Add(d.Window/2)
+		i := sort.Search(len(samples), func(i int) bool { return !samples[i].Timestamp.Before(from) })
+		for ; i < len(samples) && !samples[i].Timestamp.After(to); i++ {
+			n++
+			sum += samples[i].Value
+			sumSquares += samples[i].Value * samples[i].Value
+		}
+	}
+	if n < d.MinSamples {
+		return dynamicBounds{samples: n}, false
+	}
+
+	mean := sum / float64(n)
+	stddev := math.Sqrt(math.Max(sumSquares/float64(n)-mean*mean, 0))
+	return dynamicBounds{
+		mean:    mean,
+		stddev:  stddev,
+		lower:   mean - d.Sensitivity*stddev,
+		upper:   mean + d.Sensitivity*stddev,
+		samples: n,
+	}, true
+}
+
+// breached 判断指标值是否越过阈值
+func (d DynamicThreshold) breached(value float64, bounds dynamicBounds) bool {
+	switch d.Direction {
+	case DynamicDirectionBelow:
+		return value < bounds.lower
+	case DynamicDirectionBoth:
+		return value < bounds.lower || value > bounds.upper
+	default:
+		return value > bounds.upper
+	}
+}
+
+// threshold 返回越界方向上的阈值，用于告警记录
+func (d DynamicThreshold) threshold(value float64, bounds dynamicBounds) float64 {
+	if d.Direction == DynamicDirectionBelow || (d.Direction == DynamicDirectionBoth && value < bounds.lower) {
+		return bounds.lower
+	}
+	return bounds.upper
+}
+
+// AlertBacktestFiring 回测中的一次告警
+type AlertBacktestFiring struct {
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"` // 为空表示回测结束时仍在告警
+	Threshold float64    `json:"threshold"`          // 触发时的阈值
+	MinValue  float64    `json:"min_value"`
+	MaxValue  float64    `json:"max_value"`
+}
+
+// AlertBacktestResult 告警规则回测结果
+type AlertBacktestResult struct {
+	Metric        string                `json:"metric"`
+	Start         time.Time             `json:"start"`
+	End           time.Time             `json:"end"`
+	Samples       int                   `json:"samples"`   // 回测区间内的采样数
+	Evaluated     int                   `json:"evaluated"` // 能够计算阈值并参与判断的采样数
+	FiringCount   int                   `json:"firing_count"`
+	FiringSeconds float64               `json:"firing_seconds"` // 处于告警状态的总时长
+	Firings       []AlertBacktestFiring `json:"firings"`
+}
+
+// SetMetricHistory 设置指标历史服务，动态阈值规则和回测需要
+func (a *AlertService) SetMetricHistory(history *MetricHistoryService) {
+	a.metricHistory = history
+}
+
+// evaluateDynamicRule 按历史采样计算阈值并判断当前值是否越界
+func (a *AlertService) evaluateDynamicRule(rule *AlertRule, value float64, now time.Time) bool {
+	delete(a.dynamicBounds, rule.ID)
+	if a.metricHistory == nil {
+		return false
+	}
+
+	d := *rule.Dynamic
+	samples, err := a.metricHistory.Query(rule.Metric, now.Add(-d.lookback()), now.Add(-d.Seasonality+d.Window/2))
+	if err != nil {
+		log.Printf("查询指标历史失败: rule=%s, error=%v", rule.ID, err)
+		return false
+	}
+	bounds, ok := computeDynamicBounds(samples, now, d)
+	if !ok {
+		return false
+	}
+	a.dynamicBounds[rule.ID] = bounds
+	return d.breached(value, bounds)
+}
+
+// BacktestRule 使用指标历史回放单指标规则，返回区间内每次告警的起止时间
+// 持续时间按规则的 Duration 计算，条件不满足即视为恢复
+func (a *AlertService) BacktestRule(rule *AlertRule, start, end time.Time) (*AlertBacktestResult, error) {
+	if a.metricHistory == nil {
+		return nil, ErrMetricHistoryUnavailable
+	}
+	if err := a.prepareRule(rule); err != nil {
+		return nil, err
+	}
+	if rule.expression != nil {
+		return nil, fmt.Errorf("回测仅支持单指标规则")
+	}
+	if !end.After(start) {
+		return nil, fmt.Errorf("回测结束时间必须晚于开始时间")
+	}
+
+	var lookback time.Duration
+	if rule.Type == AlertRuleTypeDynamic {
+		lookback = rule.Dynamic.lookback()
+	}
+	samples, err := a.metricHistory.Query(rule.Metric, start.Add(-lookback), end)
+	if err != nil {
+		return nil, fmt.Errorf("查询指标历史失败: %w", err)
+	}
+
+	result := &AlertBacktestResult{Metric: rule.Metric, Start: start, End: end, Firings: []AlertBacktestFiring{}}
+	var since time.Time
+	var current *AlertBacktestFiring
+	for _, sample := range samples {
+		if sample.Timestamp.Before(start) {
+			continue
+		}
+		result.Samples++
+
+		breached, threshold := false, rule.Threshold
+		if rule.Type == AlertRuleTypeDynamic {
+			if bounds, ok := computeDynamicBounds(samples, sample.Timestamp, *rule.Dynamic); ok {
+				result.Evaluated++
+				breached = rule.Dynamic.breached(sample.Value, bounds)
+				threshold = rule.Dynamic.threshold(sample.Value, bounds)
+			}
+		} else {
+			result.Evaluated++
+			breached = a.shouldTriggerAlert(rule, sample.Value)
+		}
+
+		if !breached {
+			since = time.Time{}
+			if current != nil {
+				endedAt := sample.Timestamp
+				current.EndedAt = &endedAt
+				result.Firings = append(result.Firings, *current)
+				current = nil
+			}
+			continue
+		}
+		if since.IsZero() {
+			since = sample.Timestamp
+		}
+		if current == nil && sample.Timestamp.Sub(since) >= rule.window() {
+			current = &AlertBacktestFiring{
+				StartedAt: sample.Timestamp,
+				Threshold: threshold,
+				MinValue:  sample.Value,
+				MaxValue:  sample.Value,
+			}
+		} else if current != nil {
+			current.MinValue = math.Min(current.MinValue, sample.Value)
+			current.MaxValue = math.Max(current.MaxValue, sample.Value)
+		}
+	}
+	if current != nil {
+		result.Firings = append(result.Firings, *current)
+	}
+
+	for _, firing := range result.Firings {
+		endedAt := end
+		if firing.EndedAt != nil {
+			endedAt = *firing.EndedAt
+		}
+		result.FiringSeconds += endedAt.Sub(firing.StartedAt).Seconds()
+	}
+	result.FiringCount = len(result.Firings)
+	return result, nil
+}
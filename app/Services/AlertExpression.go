@@ -0,0 +1,327 @@
+package Services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// AlertExpression 组合告警条件
+// 功能说明：
+// 1. 支持多个指标比较的布尔组合，例如 "cpu_usage > 80 AND error_rate > 5 FOR 5m"
+// 2. 逻辑运算符 AND/OR/NOT（也可写作 &&、||、!），支持括号；比较运算符 > >= < <= == !=
+// 3. rate(metric) 为时间窗口内每秒变化率，delta(metric) 为时间窗口内变化量，用于变化速率告警
+// 4. 末尾的 FOR <时长> 表示条件需持续满足的时间，覆盖规则的 Duration
+type AlertExpression struct {
+	source   string
+	root     alertExprNode
+	metrics  []string
+	usesRate bool
+	For      time.Duration
+}
+
+// alertMetricLookup 按函数和指标名获取比较值，数据不足时返回 false
+type alertMetricLookup func(fn, metric string) (float64, bool)
+
+type alertExprNode interface {
+	eval(lookup alertMetricLookup) bool
+}
+
+type alertAndNode struct{ left, right alertExprNode }
+
+func (n alertAndNode) eval(lookup alertMetricLookup) bool {
+	return n.left.eval(lookup) && n.right.eval(lookup)
+}
+
+type alertOrNode struct{ left, right alertExprNode }
+
+func (n alertOrNode) eval(lookup alertMetricLookup) bool {
+	return n.left.eval(lookup) || n.right.eval(lookup)
+}
+
+type alertNotNode struct{ operand alertExprNode }
+
+func (n alertNotNode) eval(lookup alertMetricLookup) bool {
+	return !n.operand.eval(lookup)
+}
+
+type alertCompareNode struct {
+	fn        string // 空、rate 或 delta
+	metric    string
+	op        string
+	threshold float64
+}
+
+func (n alertCompareNode) eval(lookup alertMetricLookup) bool {
+	value, ok := lookup(n.fn, n.metric)
+	if !ok {
+		return false
+	}
+	return compareAlertValue(value, n.op, n.threshold)
+}
+
+// compareAlertValue 按比较运算符比较指标值和阈值
+func compareAlertValue(value float64, op string, threshold float64) bool {
+	switch op {
+	case ">":
+		return value > threshold
+	case ">=":
+		return value >= threshold
+	case "<":
+		return value < threshold
+	case "<=":
+		return value <= threshold
+	case "==":
+		return value == threshold
+	case "!=":
+		return value != threshold
+	default:
+		return false
+	}
+}
+
+// ParseAlertExpression 解析组合告警条件
+func ParseAlertExpression(source string) (*AlertExpression, error) {
+	tokens, err := tokenizeAlertExpression(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &alertExprParser{tokens: tokens, metrics: make(map[string]bool)}
+
+	expr := &AlertExpression{source: source}
+	expr.root, err = p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peekKeyword("FOR") {
+		p.pos++
+		token, ok := p.next()
+		if !ok {
+			return nil, fmt.Errorf("FOR 后缺少持续时间")
+		}
+		if expr.For, err = time.ParseDuration(token); err != nil || expr.For <= 0 {
+			return nil, fmt.Errorf("无效的持续时间: %s", token)
+		}
+	}
+	if token, ok := p.next(); ok {
+		return nil, fmt.Errorf("无法解析的内容: %s", token)
+	}
+
+	for metric := range p.metrics {
+		expr.metrics = append(expr.metrics, metric)
+	}
+	sort.Strings(expr.metrics)
+	expr.usesRate = p.usesRate
+	return expr, nil
+}
+
+// Metrics 返回条件中引用的指标
+func (e *AlertExpression) Metrics() []string {
+	return e.metrics
+}
+
+// UsesRate 条件中是否包含 rate 或 delta
+func (e *AlertExpression) UsesRate() bool {
+	return e.usesRate
+}
+
+// String 返回原始条件
+func (e *AlertExpression) String() string {
+	return e.source
+}
+
+// Evaluate 使用给定的取值函数计算条件
+func (e *AlertExpression) Evaluate(lookup func(fn, metric string) (float64, bool)) bool {
+	return e.root.eval(lookup)
+}
+
+// tokenizeAlertExpression 拆分条件中的标识符、数字、运算符和括号
+func tokenizeAlertExpression(source string) ([]string, error) {
+	var tokens []string
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, string(r))
+			i++
+		case strings.ContainsRune("<>=!&|", r):
+			j := i + 1
+			if j < len(runes) && strings.ContainsRune("=&|", runes[j]) {
+				j++
+			}
+			op := string(runes[i:j])
+			switch op {
+			case "&&":
+				op = "AND"
+			case "||":
+				op = "OR"
+			case "!":
+				op = "NOT"
+			default:
+				if !isAlertCompareOp(op) {
+					return nil, fmt.Errorf("无效的运算符: %s", op)
+				}
+			}
+			tokens = append(tokens, op)
+			i = j
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || strings.ContainsRune("_.-", runes[j])) {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		default:
+			return nil, fmt.Errorf("无效的字符: %c", r)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("告警条件不能为空")
+	}
+	return tokens, nil
+}
+
+// alertExprParser 递归下降解析器，优先级 NOT > AND > OR
+type alertExprParser struct {
+	tokens   []string
+	pos      int
+	metrics  map[string]bool
+	usesRate bool
+}
+
+func (p *alertExprParser) next() (string, bool) {
+	if p.pos >= len(p.tokens) {
+		return "", false
+	}
+	p.pos++
+	return p.tokens[p.pos-1], true
+}
+
+func (p *alertExprParser) peekKeyword(keyword string) bool {
+	return p.pos < len(p.tokens) && strings.EqualFold(p.tokens[p.pos], keyword)
+}
+
+func (p *alertExprParser) parseOr() (alertExprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("OR") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = alertOrNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *alertExprParser) parseAnd() (alertExprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("AND") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = alertAndNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *alertExprParser) parseUnary() (alertExprNode, error) {
+	if p.peekKeyword("NOT") {
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return alertNotNode{operand: operand}, nil
+	}
+	if p.pos < len(p.tokens) && p.tokens[p.pos] == "(" {
+		p.pos++
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if token, ok := p.next(); !ok || token != ")" {
+			return nil, fmt.Errorf("缺少右括号")
+		}
+		return node, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *alertExprParser) parseComparison() (alertExprNode, error) {
+	node := alertCompareNode{}
+	name, ok := p.next()
+	if !ok {
+		return nil, fmt.Errorf("缺少指标名称")
+	}
+	if fn := strings.ToLower(name); (fn == "rate" || fn == "delta") && p.pos < len(p.tokens) && p.tokens[p.pos] == "(" {
+		p.pos++
+		if name, ok = p.next(); !ok {
+			return nil, fmt.Errorf("%s() 缺少指标名称", fn)
+		}
+		if token, ok := p.next(); !ok || token != ")" {
+			return nil, fmt.Errorf("%s() 缺少右括号", fn)
+		}
+		node.fn = fn
+	}
+	if !isAlertMetricName(name) {
+		return nil, fmt.Errorf("无效的指标名称: %s", name)
+	}
+	node.metric = name
+
+	if node.op, ok = p.next(); !ok || !isAlertCompareOp(node.op) {
+		return nil, fmt.Errorf("指标 %s 后缺少比较运算符", name)
+	}
+	value, ok := p.next()
+	if !ok {
+		return nil, fmt.Errorf("指标 %s 缺少阈值", name)
+	}
+	threshold, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("无效的阈值: %s", value)
+	}
+	node.threshold = threshold
+
+	p.metrics[name] = true
+	if node.fn != "" {
+		p.usesRate = true
+	}
+	return node, nil
+}
+
+// isAlertCompareOp 是否为比较运算符
+func isAlertCompareOp(op string) bool {
+	switch op {
+	case ">", ">=", "<", "<=", "==", "!=":
+		return true
+	default:
+		return false
+	}
+}
+
+// isAlertMetricName 指标名称以字母或下划线开头，且不是关键字
+func isAlertMetricName(name string) bool {
+	if name == "" {
+		return false
+	}
+	switch strings.ToUpper(name) {
+	case "AND", "OR", "NOT", "FOR":
+		return false
+	}
+	first := []rune(name)[0]
+	return unicode.IsLetter(first) || first == '_'
+}
@@ -1,7 +1,13 @@
 package Services
 
 import (
+	"cloud-platform-api/app/Config"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"log"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -22,36 +28,102 @@ const (
 	AlertChannelEmail   AlertChannel = "email"
 	AlertChannelSlack   AlertChannel = "slack"
 	AlertChannelWebhook AlertChannel = "webhook"
+	AlertChannelSMS     AlertChannel = "sms"
 )
 
 // AlertRule 告警规则
 type AlertRule struct {
-	ID          string         `json:"id"`
-	Name        string         `json:"name"`
-	Description string         `json:"description"`
-	Metric      string         `json:"metric"`
-	Condition   string         `json:"condition"`
-	Threshold   float64        `json:"threshold"`
-	Duration    time.Duration  `json:"duration"`
-	Level       AlertLevel     `json:"level"`
-	Channels    []AlertChannel `json:"channels"`
-	Enabled     bool           `json:"enabled"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Metric      string            `json:"metric"`
+	Condition   string            `json:"condition"`
+	Threshold   float64           `json:"threshold"`
+	Type        string            `json:"type,omitempty"`       // static（默认）或 dynamic
+	Dynamic     *DynamicThreshold `json:"dynamic,omitempty"`    // 动态阈值配置，Type 为 dynamic 时使用
+	Expression  string            `json:"expression,omitempty"` // 组合条件，设置后忽略 Metric/Condition/Threshold
+	Duration    time.Duration     `json:"duration"`             // 条件需持续满足的时间，为0时单次满足即触发
+	Level       AlertLevel        `json:"level"`
+	Channels    []AlertChannel    `json:"channels"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Enabled     bool              `json:"enabled"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+
+	expression *AlertExpression
 }
 
 // Alert 告警实例
+// 同一指纹的告警在解决前只保留一条，重复触发时累加 Count 并更新 LastSeen
 type Alert struct {
-	ID         string     `json:"id"`
-	RuleID     string     `json:"rule_id"`
-	Level      AlertLevel `json:"level"`
-	Message    string     `json:"message"`
-	Metric     string     `json:"metric"`
-	Value      float64    `json:"value"`
-	Threshold  float64    `json:"threshold"`
-	Status     string     `json:"status"`
-	CreatedAt  time.Time  `json:"created_at"`
-	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	ID          string     `json:"id"`
+	RuleID      string     `json:"rule_id"`
+	Fingerprint string     `json:"fingerprint"`
+	Level       AlertLevel `json:"level"`
+	Message     string     `json:"message"`
+	Metric      string     `json:"metric"`
+	Value       float64    `json:"value"`
+	Threshold   float64    `json:"threshold"`
+	Status      string     `json:"status"`
+	Count       int        `json:"count"`
+	LastSeen    time.Time  `json:"last_seen"`
+	Flapping    bool       `json:"flapping"`
+	ClearedAt   *time.Time `json:"cleared_at,omitempty"` // 条件恢复时间，稳定后才解决
+	CreatedAt   time.Time  `json:"created_at"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+
+	// 外部监控系统推送的告警记录来源、标签和注解，规则告警为空
+	Source      string            `json:"source,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	transitions []time.Time
+}
+
+// ExternalAlert 外部监控系统推送的告警
+type ExternalAlert struct {
+	Source      string            `json:"source"`
+	Name        string            `json:"name"`
+	Resolved    bool              `json:"resolved"`
+	Level       AlertLevel        `json:"level"`
+	Message     string            `json:"message"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	StartsAt    time.Time         `json:"starts_at"`
+	EndsAt      time.Time         `json:"ends_at"`
+}
+
+// rule 外部告警对应的规则，同一来源的同名告警归入同一规则，不参与规则评估
+func (e ExternalAlert) rule() *AlertRule {
+	return &AlertRule{
+		ID:     fmt.Sprintf("external_%s_%s", e.Source, e.Name),
+		Name:   e.Name,
+		Level:  e.Level,
+		Labels: e.Labels,
+	}
+}
+
+// AlertFingerprint 计算告警指纹
+// 由规则、指标和按键排序的标签计算，相同条件重复触发得到相同指纹
+func AlertFingerprint(ruleID, metric string, labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(ruleID)
+	b.WriteByte(0)
+	b.WriteString(metric)
+	for _, key := range keys {
+		b.WriteByte(0)
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(labels[key])
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
 }
 
 // AlertService 告警服务
@@ -60,20 +132,96 @@ type AlertService struct {
 	monitoringService *OptimizedMonitoringService
 	rules             map[string]*AlertRule
 	alerts            map[string]*Alert
+	openAlerts        map[string]*Alert // 按指纹索引的未解决告警
+	smsChannel        NotificationChannel
+	pipeline          *NotificationPipeline
+	metricProvider    func(metric string) (float64, error)
+	onTransition      func(alert Alert, transition string)
+
+	samples      map[string][]alertSample // 按指标保存的采样窗口，用于持续时间和变化速率计算
+	pendingSince map[string]time.Time     // 规则条件开始持续满足的时间
+
+	metricHistory *MetricHistoryService
+	dynamicBounds map[string]dynamicBounds // 动态阈值规则最近一次计算的阈值
+
+	resolveStableFor time.Duration
+	flapWindow       time.Duration
+	flapThreshold    int
 }
 
 // NewAlertService 创建告警服务
+//
+// 恢复稳定期和抖动检测使用监控告警配置（MONITORING_ALERT_*）。
 func NewAlertService(emailService *EmailService, monitoringService *OptimizedMonitoringService) *AlertService {
+	alertConfig := Config.MonitoringConfig{}
+	alertConfig.SetDefaults()
+	if globalConfig := Config.GetConfig(); globalConfig != nil {
+		alertConfig = globalConfig.Monitoring
+	}
+
 	return &AlertService{
 		emailService:      emailService,
 		monitoringService: monitoringService,
 		rules:             make(map[string]*AlertRule),
 		alerts:            make(map[string]*Alert),
+		openAlerts:        make(map[string]*Alert),
+		samples:           make(map[string][]alertSample),
+		pendingSince:      make(map[string]time.Time),
+		dynamicBounds:     make(map[string]dynamicBounds),
+		resolveStableFor:  alertConfig.AlertConfig.ResolveStableFor,
+		flapWindow:        alertConfig.AlertConfig.FlapWindow,
+		flapThreshold:     alertConfig.AlertConfig.FlapThreshold,
+	}
+}
+
+// SetResolutionPolicy 设置恢复稳定期和抖动检测参数
+// 条件恢复后需持续 stableFor 才解决告警；flapWindow 内触发/恢复切换达到 flapThreshold 次时视为抖动，需稳定整个窗口才解决
+func (a *AlertService) SetResolutionPolicy(stableFor, flapWindow time.Duration, flapThreshold int) {
+	a.resolveStableFor = stableFor
+	a.flapWindow = flapWindow
+	a.flapThreshold = flapThreshold
+}
+
+// alertSample 指标采样
+type alertSample struct {
+	at    time.Time
+	value float64
+}
+
+// SetMetricProvider 设置指标取值函数，未设置时使用内置指标
+func (a *AlertService) SetMetricProvider(provider func(metric string) (float64, error)) {
+	a.metricProvider = provider
+}
+
+// SetTransitionListener 设置告警状态变化监听，告警触发和解决时以告警快照调用
+// 监听在告警评估过程中同步调用，不应阻塞
+func (a *AlertService) SetTransitionListener(listener func(alert Alert, transition string)) {
+	a.onTransition = listener
+}
+
+// notifyTransition 通知告警状态变化
+func (a *AlertService) notifyTransition(alert *Alert, transition string) {
+	if a.onTransition != nil {
+		a.onTransition(*alert, transition)
 	}
 }
 
+// SetSMSChannel 设置短信告警通道，规则渠道包含 sms 时使用
+func (a *AlertService) SetSMSChannel(channel NotificationChannel) {
+	a.smsChannel = channel
+}
+
+// SetNotificationPipeline 设置通知管道
+// 规则渠道中除 email 外的通道通过管道发送，规则未指定渠道时发送到管道的全部通道
+func (a *AlertService) SetNotificationPipeline(pipeline *NotificationPipeline) {
+	a.pipeline = pipeline
+}
+
 // AddRule 添加告警规则
 func (a *AlertService) AddRule(rule *AlertRule) error {
+	if err := a.prepareRule(rule); err != nil {
+		return err
+	}
 	if rule.ID == "" {
 		rule.ID = fmt.Sprintf("rule_%d", time.Now().UnixNano())
 	}
@@ -85,6 +233,60 @@ func (a *AlertService) AddRule(rule *AlertRule) error {
 	return nil
 }
 
+// prepareRule 校验规则，解析组合条件并补全动态阈值默认参数
+func (a *AlertService) prepareRule(rule *AlertRule) error {
+	switch rule.Type {
+	case "", AlertRuleTypeStatic:
+		rule.Type = AlertRuleTypeStatic
+	case AlertRuleTypeDynamic:
+		if rule.Metric == "" || rule.Expression != "" {
+			return fmt.Errorf("动态阈值规则需要单个指标，不支持组合条件")
+		}
+		dynamic := DynamicThreshold{}
+		if rule.Dynamic != nil {
+			dynamic = *rule.Dynamic
+		}
+		dynamic = dynamic.withDefaults()
+		if err := dynamic.validate(); err != nil {
+			return err
+		}
+		rule.Dynamic = &dynamic
+		return nil
+	default:
+		return fmt.Errorf("无效的规则类型: %s", rule.Type)
+	}
+
+	if rule.Expression != "" {
+		expression, err := ParseAlertExpression(rule.Expression)
+		if err != nil {
+			return fmt.Errorf("告警条件无效: %w", err)
+		}
+		rule.expression = expression
+	} else if rule.Metric == "" {
+		return fmt.Errorf("告警规则缺少指标或组合条件")
+	}
+	return nil
+}
+
+// RemoveRule 移除告警规则，规则未解决的告警直接标记为已解决，不发送恢复通知
+func (a *AlertService) RemoveRule(ruleID string) {
+	rule, exists := a.rules[ruleID]
+	if !exists {
+		return
+	}
+	delete(a.rules, ruleID)
+	delete(a.pendingSince, ruleID)
+	delete(a.dynamicBounds, ruleID)
+
+	fingerprint := a.fingerprint(rule)
+	if alert, open := a.openAlerts[fingerprint]; open {
+		now := time.Now()
+		alert.Status = "resolved"
+		alert.ResolvedAt = &now
+		delete(a.openAlerts, fingerprint)
+	}
+}
+
 // GetRules 获取所有告警规则
 func (a *AlertService) GetRules() []*AlertRule {
 	rules := make([]*AlertRule, 0, len(a.rules))
@@ -95,27 +297,178 @@ func (a *AlertService) GetRules() []*AlertRule {
 }
 
 // CheckAlerts 检查告警
+// 功能说明：
+// 1. 每个指标每轮只取值一次，追加到采样窗口，窗口长度为规则中最长的持续时间
+// 2. 条件需在持续时间内的每次检查都满足才创建告警，中途不满足则重新计时
+// 3. 已有未解决告警时条件满足即视为再次触发，条件不满足时进入恢复判断
 func (a *AlertService) CheckAlerts() error {
+	now := time.Now()
+	values := make(map[string]float64)
+	failed := make(map[string]bool)
+	var retention time.Duration
+
 	for _, rule := range a.rules {
 		if !rule.Enabled {
 			continue
 		}
+		if window := rule.window(); window > retention {
+			retention = window
+		}
+		for _, metric := range rule.metrics() {
+			if _, done := values[metric]; done || failed[metric] {
+				continue
+			}
+			value, err := a.metricValue(metric)
+			if err != nil {
+				failed[metric] = true
+				continue
+			}
+			values[metric] = value
+		}
+	}
+	for metric, value := range values {
+		a.recordSample(metric, value, now, retention)
+	}
 
-		value, err := a.getMetricValue(rule.Metric)
-		if err != nil {
+	for _, rule := range a.rules {
+		if !rule.Enabled {
+			continue
+		}
+		skip := false
+		for _, metric := range rule.metrics() {
+			skip = skip || failed[metric]
+		}
+		if skip {
 			continue
 		}
 
-		if a.shouldTriggerAlert(rule, value) {
-			a.triggerAlert(rule, value)
-		} else {
+		if !a.evaluateRule(rule, values, now) {
+			delete(a.pendingSince, rule.ID)
 			a.resolveAlert(rule)
+			continue
+		}
+
+		since, pending := a.pendingSince[rule.ID]
+		if !pending {
+			since = now
+			a.pendingSince[rule.ID] = now
+		}
+		if _, open := a.openAlerts[a.fingerprint(rule)]; open || now.Sub(since) >= rule.window() {
+			a.triggerAlert(rule, values[rule.Metric], a.alertMessage(rule, values))
 		}
 	}
 
 	return nil
 }
 
+// metrics 返回规则引用的指标
+func (r *AlertRule) metrics() []string {
+	if r.expression != nil {
+		return r.expression.Metrics()
+	}
+	return []string{r.Metric}
+}
+
+// window 返回条件需持续满足的时间，组合条件中的 FOR 优先
+func (r *AlertRule) window() time.Duration {
+	if r.expression != nil && r.expression.For > 0 {
+		return r.expression.For
+	}
+	return r.Duration
+}
+
+// metricValue 获取指标值
+func (a *AlertService) metricValue(metric string) (float64, error) {
+	if a.metricProvider != nil {
+		return a.metricProvider(metric)
+	}
+	return a.getMetricValue(metric)
+}
+
+// recordSample 记录指标采样，只保留 retention 内的采样，且至少保留最近两个用于计算变化速率
+func (a *AlertService) recordSample(metric string, value float64, now time.Time, retention time.Duration) {
+	samples := append(a.samples[metric], alertSample{at: now, value: value})
+	cutoff := now.Add(-retention)
+	start := 0
+	for start < len(samples)-2 && samples[start].at.Before(cutoff) {
+		start++
+	}
+	a.samples[metric] = append([]alertSample(nil), samples[start:]...)
+}
+
+// metricChange 计算指标在窗口内的变化量和经过的时间
+// window 为0时使用最近两次采样
+func (a *AlertService) metricChange(metric string, window time.Duration, now time.Time) (float64, time.Duration, bool) {
+	samples := a.samples[metric]
+	if len(samples) < 2 {
+		return 0, 0, false
+	}
+	last := samples[len(samples)-1]
+	first := samples[len(samples)-2]
+	if window > 0 {
+		cutoff := now.Add(-window)
+		for _, sample := range samples {
+			if !sample.at.Before(cutoff) {
+				first = sample
+				break
+			}
+		}
+	}
+	elapsed := last.at.Sub(first.at)
+	if elapsed <= 0 {
+		return 0, 0, false
+	}
+	return last.value - first.value, elapsed, true
+}
+
+// evaluateRule 计算规则条件在本轮是否满足
+func (a *AlertService) evaluateRule(rule *AlertRule, values map[string]float64, now time.Time) bool {
+	if rule.Type == AlertRuleTypeDynamic {
+		return a.evaluateDynamicRule(rule, values[rule.Metric], now)
+	}
+	if rule.expression == nil {
+		return a.shouldTriggerAlert(rule, values[rule.Metric])
+	}
+	return rule.expression.Evaluate(func(fn, metric string) (float64, bool) {
+		switch fn {
+		case "rate":
+			delta, elapsed, ok := a.metricChange(metric, rule.window(), now)
+			return delta / elapsed.Seconds(), ok
+		case "delta":
+			delta, _, ok := a.metricChange(metric, rule.window(), now)
+			return delta, ok
+		default:
+			value, ok := values[metric]
+			return value, ok
+		}
+	})
+}
+
+// alertMessage 生成告警消息，组合条件列出所有相关指标的当前值
+func (a *AlertService) alertMessage(rule *AlertRule, values map[string]float64) string {
+	if bounds, ok := a.dynamicBounds[rule.ID]; ok && rule.Type == AlertRuleTypeDynamic {
+		return fmt.Sprintf("指标 %s 当前值为 %.2f，超出动态阈值范围 [%.2f, %.2f]（基线均值 %.2f，%d 个历史采样）",
+			rule.Metric, values[rule.Metric], bounds.lower, bounds.upper, bounds.mean, bounds.samples)
+	}
+	if rule.expression == nil {
+		return fmt.Sprintf("指标 %s 当前值为 %.2f，超过阈值 %.2f", rule.Metric, values[rule.Metric], rule.Threshold)
+	}
+	parts := make([]string, 0, len(rule.expression.Metrics()))
+	for _, metric := range rule.expression.Metrics() {
+		parts = append(parts, fmt.Sprintf("%s=%.2f", metric, values[metric]))
+	}
+	return fmt.Sprintf("条件 %s 已满足，当前值: %s", rule.expression, strings.Join(parts, ", "))
+}
+
+// fingerprint 计算规则告警的指纹，组合条件以条件表达式代替指标名
+func (a *AlertService) fingerprint(rule *AlertRule) string {
+	metric := rule.Metric
+	if rule.expression != nil {
+		metric = rule.expression.String()
+	}
+	return AlertFingerprint(rule.ID, metric, rule.Labels)
+}
+
 // getMetricValue 获取指标值
 func (a *AlertService) getMetricValue(metric string) (float64, error) {
 	switch metric {
@@ -134,72 +487,223 @@ func (a *AlertService) getMetricValue(metric string) (float64, error) {
 
 // shouldTriggerAlert 检查是否应该触发告警
 func (a *AlertService) shouldTriggerAlert(rule *AlertRule, value float64) bool {
-	switch rule.Condition {
-	case ">":
-		return value > rule.Threshold
-	case ">=":
-		return value >= rule.Threshold
-	case "<":
-		return value < rule.Threshold
-	case "<=":
-		return value <= rule.Threshold
-	default:
-		return false
-	}
+	return compareAlertValue(value, rule.Condition, rule.Threshold)
 }
 
 // triggerAlert 触发告警
-func (a *AlertService) triggerAlert(rule *AlertRule, value float64) {
-	alertID := fmt.Sprintf("%s_%d", rule.ID, time.Now().Unix())
+// 同一指纹已有未解决告警时只累加次数，不重复创建告警和发送通知
+func (a *AlertService) triggerAlert(rule *AlertRule, value float64, message string) {
+	now := time.Now()
+	fingerprint := a.fingerprint(rule)
+
+	if alert, exists := a.openAlerts[fingerprint]; exists {
+		alert.Count++
+		alert.LastSeen = now
+		alert.Value = value
+		alert.Message = message
+		// 等待解决期间条件再次触发，记为一次抖动
+		if alert.ClearedAt != nil {
+			alert.ClearedAt = nil
+			a.recordTransition(alert, now)
+		}
+		return
+	}
 
+	threshold := rule.Threshold
+	if bounds, ok := a.dynamicBounds[rule.ID]; ok && rule.Type == AlertRuleTypeDynamic {
+		threshold = rule.Dynamic.threshold(value, bounds)
+	}
+
+	alertID := fmt.Sprintf("%s_%d", rule.ID, now.UnixNano())
 	alert := &Alert{
-		ID:        alertID,
-		RuleID:    rule.ID,
-		Level:     rule.Level,
-		Message:   fmt.Sprintf("指标 %s 当前值为 %.2f，超过阈值 %.2f", rule.Metric, value, rule.Threshold),
-		Metric:    rule.Metric,
-		Value:     value,
-		Threshold: rule.Threshold,
-		Status:    "active",
-		CreatedAt: time.Now(),
+		ID:          alertID,
+		RuleID:      rule.ID,
+		Fingerprint: fingerprint,
+		Level:       rule.Level,
+		Message:     message,
+		Metric:      strings.Join(rule.metrics(), ","),
+		Value:       value,
+		Threshold:   threshold,
+		Status:      "active",
+		Count:       1,
+		LastSeen:    now,
+		CreatedAt:   now,
 	}
 
 	a.alerts[alertID] = alert
+	a.openAlerts[fingerprint] = alert
+	a.sendAlertNotifications(alert, rule)
+	a.notifyTransition(alert, AlertTransitionTriggered)
+}
+
+// ReceiveExternal 接收外部告警，返回对应的告警实例和状态变化，重复推送或恢复未知告警时状态变化为空
+// 功能说明：
+// 1. 指纹由来源、告警名称和标签计算，同一告警在解决前重复推送只累加次数，不重复发送通知
+// 2. 外部系统已经处理了持续时间和恢复判断，恢复时立即解决，不经过稳定期
+// 3. 触发和解决与规则告警一样发送通知、发布领域事件和通知状态变化监听（事件、实时事件流）
+func (a *AlertService) ReceiveExternal(external ExternalAlert) (*Alert, string) {
+	now := time.Now()
+	rule := external.rule()
+	fingerprint := AlertFingerprint(rule.ID, external.Name, external.Labels)
+	alert, open := a.openAlerts[fingerprint]
+
+	if external.Resolved {
+		if !open {
+			return nil, ""
+		}
+		resolvedAt := now
+		if !external.EndsAt.IsZero() && external.EndsAt.Before(now) {
+			resolvedAt = external.EndsAt
+		}
+		alert.Status = "resolved"
+		alert.ResolvedAt = &resolvedAt
+		alert.LastSeen = now
+		delete(a.openAlerts, fingerprint)
+		a.sendResolveNotifications(alert, rule)
+		a.notifyTransition(alert, AlertTransitionResolved)
+		return alert, AlertTransitionResolved
+	}
+
+	if open {
+		alert.Count++
+		alert.LastSeen = now
+		alert.Message = external.Message
+		alert.Annotations = external.Annotations
+		return alert, ""
+	}
+
+	createdAt := now
+	if !external.StartsAt.IsZero() && external.StartsAt.Before(now) {
+		createdAt = external.StartsAt
+	}
+	alert = &Alert{
+		ID:          fmt.Sprintf("%s_%d", rule.ID, now.UnixNano()),
+		RuleID:      rule.ID,
+		Fingerprint: fingerprint,
+		Level:       external.Level,
+		Message:     external.Message,
+		Metric:      external.Name,
+		Status:      "active",
+		Count:       1,
+		LastSeen:    now,
+		CreatedAt:   createdAt,
+		Source:      external.Source,
+		Labels:      external.Labels,
+		Annotations: external.Annotations,
+	}
+	a.alerts[alert.ID] = alert
+	a.openAlerts[fingerprint] = alert
 	a.sendAlertNotifications(alert, rule)
+	a.notifyTransition(alert, AlertTransitionTriggered)
+	return alert, AlertTransitionTriggered
 }
 
 // resolveAlert 恢复告警
+// 条件恢复后持续稳定才解决，抖动中的告警需稳定整个抖动检测窗口
 func (a *AlertService) resolveAlert(rule *AlertRule) {
-	for _, alert := range a.alerts {
-		if alert.RuleID == rule.ID && alert.Status == "active" {
-			now := time.Now()
-			alert.Status = "resolved"
-			alert.ResolvedAt = &now
-			a.sendResolveNotifications(alert, rule)
+	fingerprint := a.fingerprint(rule)
+	alert, exists := a.openAlerts[fingerprint]
+	if !exists {
+		return
+	}
+
+	now := time.Now()
+	if alert.ClearedAt == nil {
+		alert.ClearedAt = &now
+		a.recordTransition(alert, now)
+	}
+
+	stableFor := a.resolveStableFor
+	if alert.Flapping && a.flapWindow > stableFor {
+		stableFor = a.flapWindow
+	}
+	if now.Sub(*alert.ClearedAt) < stableFor {
+		return
+	}
+
+	alert.Status = "resolved"
+	alert.ResolvedAt = &now
+	delete(a.openAlerts, fingerprint)
+	a.sendResolveNotifications(alert, rule)
+	a.notifyTransition(alert, AlertTransitionResolved)
+}
+
+// recordTransition 记录一次触发/恢复切换，窗口内切换次数达到阈值时标记为抖动
+func (a *AlertService) recordTransition(alert *Alert, now time.Time) {
+	if a.flapThreshold <= 0 {
+		return
+	}
+	cutoff := now.Add(-a.flapWindow)
+	transitions := alert.transitions[:0]
+	for _, t := range alert.transitions {
+		if t.After(cutoff) {
+			transitions = append(transitions, t)
 		}
 	}
+	alert.transitions = append(transitions, now)
+	if len(alert.transitions) >= a.flapThreshold && !alert.Flapping {
+		alert.Flapping = true
+		log.Printf("告警状态抖动，延迟解决: rule=%s, transitions=%d", alert.RuleID, len(alert.transitions))
+	}
 }
 
 // sendAlertNotifications 发送告警通知
 func (a *AlertService) sendAlertNotifications(alert *Alert, rule *AlertRule) {
+	publishAlertEvent(EventAlertFired, alert, rule)
+	if len(rule.Channels) == 0 {
+		a.publishAlert(alert, rule)
+	}
 	for _, channel := range rule.Channels {
 		switch channel {
 		case AlertChannelEmail:
 			a.sendEmailAlert(alert, rule)
+		case AlertChannelSMS:
+			a.sendSMSAlert(alert, rule)
+		default:
+			a.publishAlert(alert, rule, string(channel))
 		}
 	}
 }
 
 // sendResolveNotifications 发送恢复通知
 func (a *AlertService) sendResolveNotifications(alert *Alert, rule *AlertRule) {
+	publishAlertEvent(EventAlertResolved, alert, rule)
+	if len(rule.Channels) == 0 {
+		a.publishAlert(alert, rule)
+	}
 	for _, channel := range rule.Channels {
 		switch channel {
 		case AlertChannelEmail:
 			a.sendEmailResolve(alert, rule)
+		case AlertChannelSMS:
+			a.sendSMSAlert(alert, rule)
+		default:
+			a.publishAlert(alert, rule, string(channel))
 		}
 	}
 }
 
+// publishAlertEvent 发布告警触发或恢复的领域事件，事件总线未启用时忽略
+func publishAlertEvent(eventType string, alert *Alert, rule *AlertRule) {
+	err := PublishDomainEvent(nil, eventType, "alert", alert.ID, map[string]interface{}{
+		"alert_id":    alert.ID,
+		"rule_id":     rule.ID,
+		"rule_name":   rule.Name,
+		"fingerprint": alert.Fingerprint,
+		"level":       alert.Level,
+		"metric":      alert.Metric,
+		"value":       alert.Value,
+		"threshold":   alert.Threshold,
+		"message":     alert.Message,
+		"status":      alert.Status,
+		"created_at":  alert.CreatedAt,
+		"resolved_at": alert.ResolvedAt,
+	})
+	if err != nil {
+		log.Printf("发布告警事件失败: alert=%s, type=%s, error=%v", alert.ID, eventType, err)
+	}
+}
+
 // sendEmailAlert 发送邮件告警
 func (a *AlertService) sendEmailAlert(alert *Alert, rule *AlertRule) {
 	subject := fmt.Sprintf("[%s] 系统告警: %s", string(alert.Level), rule.Name)
@@ -234,6 +738,67 @@ func (a *AlertService) sendEmailResolve(alert *Alert, rule *AlertRule) {
 	a.emailService.SendNotificationEmail("admin@example.com", subject, body)
 }
 
+// sendSMSAlert 发送短信告警或恢复通知
+// 未单独设置短信通道时通过通知管道发送
+func (a *AlertService) sendSMSAlert(alert *Alert, rule *AlertRule) {
+	if a.smsChannel == nil {
+		a.publishAlert(alert, rule, string(AlertChannelSMS))
+		return
+	}
+	if !a.smsChannel.IsEnabled() {
+		return
+	}
+	if err := a.smsChannel.SendAlert(a.monitoringAlert(alert, rule)); err != nil {
+		log.Printf("发送短信告警失败: rule=%s, error=%v", rule.ID, err)
+	}
+}
+
+// publishAlert 通过通知管道发送告警或恢复通知，names 为空时发送到全部通道
+func (a *AlertService) publishAlert(alert *Alert, rule *AlertRule, names ...string) {
+	if a.pipeline == nil {
+		return
+	}
+	a.pipeline.Publish(a.monitoringAlert(alert, rule), names...)
+}
+
+// monitoringAlert 转换为通知通道使用的告警信息
+func (a *AlertService) monitoringAlert(alert *Alert, rule *AlertRule) MonitoringAlert {
+	title := "系统告警: " + rule.Name
+	if alert.Status == "resolved" {
+		title = "告警已恢复: " + rule.Name
+	}
+	notification := MonitoringAlert{
+		ID:        alert.ID,
+		Type:      "alert_rule",
+		Severity:  string(alert.Level),
+		Title:     title,
+		Message:   alert.Message,
+		Source:    "alert_service",
+		Timestamp: alert.CreatedAt,
+		Metadata: map[string]interface{}{
+			"rule_id":   rule.ID,
+			"rule_name": rule.Name,
+			"metric":    alert.Metric,
+			"value":     alert.Value,
+			"threshold": alert.Threshold,
+		},
+		Resolved:   alert.Status == "resolved",
+		ResolvedAt: alert.ResolvedAt,
+	}
+	// 规则标签随通知发送，用于匹配静默
+	if len(rule.Labels) > 0 {
+		notification.Metadata["labels"] = rule.Labels
+	}
+	// 外部告警以来源作为告警来源，附带标签和注解
+	if alert.Source != "" {
+		notification.Type = "external_alert"
+		notification.Source = alert.Source
+		notification.Metadata["labels"] = alert.Labels
+		notification.Metadata["annotations"] = alert.Annotations
+	}
+	return notification
+}
+
 // GetAlerts 获取告警列表
 func (a *AlertService) GetAlerts(status string, limit int) []*Alert {
 	alerts := make([]*Alert, 0)
@@ -0,0 +1,545 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSilenceNotFound 静默不存在
+	ErrSilenceNotFound = errors.New("静默不存在")
+	// ErrSilenceExpired 静默已过期
+	ErrSilenceExpired = errors.New("静默已过期")
+	// ErrInvalidSilence 静默参数无效
+	ErrInvalidSilence = errors.New("静默参数无效")
+	// ErrMaintenanceWindowNotFound 维护窗口不存在
+	ErrMaintenanceWindowNotFound = errors.New("维护窗口不存在")
+	// ErrInvalidMaintenanceWindow 维护窗口参数无效
+	ErrInvalidMaintenanceWindow = errors.New("维护窗口参数无效")
+)
+
+// 静默状态，按当前时间计算
+const (
+	SilenceStatePending = "pending"
+	SilenceStateActive  = "active"
+	SilenceStateExpired = "expired"
+)
+
+const (
+	// maxSilenceMatchers 单个静默的最大匹配器数
+	maxSilenceMatchers = 20
+	// maxSilenceCommentLength 静默说明的最大长度
+	maxSilenceCommentLength = 1000
+)
+
+// SilenceInput 创建静默的参数
+// StartsAt 为空或早于当前时间时从当前时间开始
+type SilenceInput struct {
+	Matchers []Models.SilenceMatcher `json:"matchers" binding:"required"`
+	StartsAt time.Time               `json:"starts_at"`
+	EndsAt   time.Time               `json:"ends_at" binding:"required"`
+	Comment  string                  `json:"comment" binding:"required"`
+}
+
+// MaintenanceWindowInput 创建维护窗口的参数
+// 单次窗口设置 StartsAt/EndsAt，重复窗口设置 Schedule 和 DurationMinutes
+type MaintenanceWindowInput struct {
+	Name            string                  `json:"name" binding:"required"`
+	Description     string                  `json:"description"`
+	Matchers        []Models.SilenceMatcher `json:"matchers" binding:"required"`
+	StartsAt        *time.Time              `json:"starts_at"`
+	EndsAt          *time.Time              `json:"ends_at"`
+	Schedule        string                  `json:"schedule"`
+	DurationMinutes int                     `json:"duration_minutes"`
+}
+
+// compiledSilence 缓存中的静默及编译后的匹配器
+type compiledSilence struct {
+	silence  Models.MonitoringSilence
+	matchers []silenceMatcher
+}
+
+// silenceMatcher 编译后的匹配器
+type silenceMatcher struct {
+	Models.SilenceMatcher
+	regex *regexp.Regexp
+}
+
+// matches 标签是否满足匹配器，缺少的标签按空值比较
+func (m silenceMatcher) matches(labels map[string]string) bool {
+	value := labels[m.Name]
+	matched := value == m.Value
+	if m.regex != nil {
+		matched = m.regex.MatchString(value)
+	}
+	return matched == m.IsEqual
+}
+
+// AlertSilenceService 告警静默服务
+// 功能说明：
+// 1. 静默由匹配器、开始/结束时间、创建者和说明组成，匹配器语义与 Alertmanager 相同（=、!=、=~、!~，正则完整匹配）
+// 2. 作为通知管道的静默判断，发送前按告警标签匹配生效中的静默，被静默的告警只跳过通知，告警和事件照常记录
+// 3. 静默可按状态（pending/active/expired）列出，过期时把结束时间改为当前时间（未开始的静默改为开始时间），记录保留
+// 4. 维护窗口的当前或下一次时段自动创建关联的静默，重复窗口按 cron 计划在上一时段结束后创建下一时段，删除窗口时过期关联的静默
+//
+// 生效中和未开始的静默缓存在内存中，创建、过期和每个同步间隔刷新，多实例部署时其他实例最多延迟一个同步间隔
+type AlertSilenceService struct {
+	db     *gorm.DB
+	config *Config.MonitoringConfig
+	now    func() time.Time
+
+	mu       sync.RWMutex
+	silences []compiledSilence
+	syncMu   sync.Mutex
+}
+
+// NewAlertSilenceService 创建告警静默服务
+func NewAlertSilenceService(db *gorm.DB, config *Config.MonitoringConfig) *AlertSilenceService {
+	if config == nil {
+		config = &Config.MonitoringConfig{}
+		config.SetDefaults()
+		if globalConfig := Config.GetConfig(); globalConfig != nil {
+			config = &globalConfig.Monitoring
+		}
+	}
+	return &AlertSilenceService{db: db, config: config, now: time.Now}
+}
+
+// SetClock 设置时间函数，用于测试
+func (s *AlertSilenceService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// Attach 加载静默并设置为通知管道的静默判断
+func (s *AlertSilenceService) Attach(pipeline *NotificationPipeline) {
+	if err := s.Refresh(); err != nil {
+		log.Printf("加载告警静默失败: %v", err)
+	}
+	pipeline.SetSilencer(s)
+}
+
+// Start 按同步间隔同步维护窗口并刷新静默缓存
+func (s *AlertSilenceService) Start(ctx context.Context) {
+	s.sync()
+	go func() {
+		ticker := time.NewTicker(s.config.Silences.SyncInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.sync()
+			}
+		}
+	}()
+}
+
+// sync 同步维护窗口并刷新静默缓存，失败只记录日志
+func (s *AlertSilenceService) sync() {
+	if err := s.SyncMaintenanceWindows(); err != nil {
+		log.Printf("同步维护窗口失败: %v", err)
+	}
+	if err := s.Refresh(); err != nil {
+		log.Printf("刷新告警静默失败: %v", err)
+	}
+}
+
+// Refresh 从数据库重新加载尚未过期的静默
+func (s *AlertSilenceService) Refresh() error {
+	var silences []Models.MonitoringSilence
+	if err := s.db.Where("ends_at > ?", s.now()).Find(&silences).Error; err != nil {
+		return err
+	}
+	compiled := make([]compiledSilence, 0, len(silences))
+	for _, silence := range silences {
+		matchers, err := compileSilenceMatchers(silence.Matchers)
+		if err != nil {
+			log.Printf("跳过无效的告警静默: silence=%d, error=%v", silence.ID, err)
+			continue
+		}
+		compiled = append(compiled, compiledSilence{silence: silence, matchers: matchers})
+	}
+
+	s.mu.Lock()
+	s.silences = compiled
+	s.mu.Unlock()
+	return nil
+}
+
+// Silenced 告警是否被生效中的静默命中，返回命中的静默ID
+func (s *AlertSilenceService) Silenced(alert MonitoringAlert) (uint, bool) {
+	labels := SilenceLabels(alert)
+	now := s.now()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, entry := range s.silences {
+		if now.Before(entry.silence.StartsAt) || !now.Before(entry.silence.EndsAt) {
+			continue
+		}
+		matched := true
+		for _, matcher := range entry.matchers {
+			if !matcher.matches(labels) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return entry.silence.ID, true
+		}
+	}
+	return 0, false
+}
+
+// SilenceLabels 告警用于匹配静默的标签
+// 外部告警和带标签的规则告警使用自身标签，未设置时补充 alertname（规则名称，非规则告警为告警类型）、severity、source、type 和 metric
+func SilenceLabels(alert MonitoringAlert) map[string]string {
+	labels := make(map[string]string)
+	if values, ok := alert.Metadata["labels"].(map[string]string); ok {
+		for name, value := range values {
+			labels[name] = value
+		}
+	}
+	alertName := alert.Type
+	if ruleName, ok := alert.Metadata["rule_name"].(string); ok && ruleName != "" {
+		alertName = ruleName
+	}
+	defaults := map[string]string{
+		"alertname": alertName,
+		"severity":  alert.Severity,
+		"source":    alert.Source,
+		"type":      alert.Type,
+	}
+	if metric, ok := alert.Metadata["metric"].(string); ok {
+		defaults["metric"] = metric
+	}
+	for name, value := range defaults {
+		if _, exists := labels[name]; !exists && value != "" {
+			labels[name] = value
+		}
+	}
+	return labels
+}
+
+// CreateSilence 创建静默
+func (s *AlertSilenceService) CreateSilence(input SilenceInput, userID uint) (*Models.MonitoringSilence, error) {
+	now := s.now()
+	if input.StartsAt.IsZero() || input.StartsAt.Before(now) {
+		input.StartsAt = now
+	}
+	if _, err := compileSilenceMatchers(input.Matchers); err != nil {
+		return nil, err
+	}
+	if !input.EndsAt.After(input.StartsAt) {
+		return nil, fmt.Errorf("%w：结束时间必须晚于开始时间和当前时间", ErrInvalidSilence)
+	}
+	comment := strings.TrimSpace(input.Comment)
+	if comment == "" || len([]rune(comment)) > maxSilenceCommentLength {
+		return nil, fmt.Errorf("%w：说明不能为空且不能超过%d个字符", ErrInvalidSilence, maxSilenceCommentLength)
+	}
+
+	silence := &Models.MonitoringSilence{
+		Matchers:  input.Matchers,
+		StartsAt:  input.StartsAt,
+		EndsAt:    input.EndsAt,
+		CreatedBy: userID,
+		Comment:   comment,
+	}
+	if err := s.db.Create(silence).Error; err != nil {
+		return nil, err
+	}
+	s.refreshAfterChange()
+	silence.Status = silenceState(silence, now)
+	return silence, nil
+}
+
+// ListSilences 分页查询静默，state 不为空时按状态筛选
+func (s *AlertSilenceService) ListSilences(q *Utils.ListQuery, req Utils.PageRequest, state string) ([]Models.MonitoringSilence, Utils.PageMeta, error) {
+	now := s.now()
+	db := s.db.Model(&Models.MonitoringSilence{})
+	switch state {
+	case "":
+	case SilenceStatePending:
+		db = db.Where("starts_at > ? AND ends_at > starts_at", now)
+	case SilenceStateActive:
+		db = db.Where("starts_at <= ? AND ends_at > ?", now, now)
+	case SilenceStateExpired:
+		db = db.Where("ends_at <= ? OR ends_at <= starts_at", now)
+	default:
+		return nil, Utils.PageMeta{}, fmt.Errorf("%w：未知状态 %s", ErrInvalidSilence, state)
+	}
+
+	query, order, err := q.Apply(db, req)
+	if err != nil {
+		return nil, Utils.PageMeta{}, err
+	}
+	var silences []Models.MonitoringSilence
+	meta, err := Utils.Paginate(query, req, order, &silences)
+	for i := range silences {
+		silences[i].Status = silenceState(&silences[i], now)
+	}
+	return silences, meta, err
+}
+
+// GetSilence 获取静默
+func (s *AlertSilenceService) GetSilence(id uint) (*Models.MonitoringSilence, error) {
+	var silence Models.MonitoringSilence
+	if err := s.db.First(&silence, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSilenceNotFound
+		}
+		return nil, err
+	}
+	silence.Status = silenceState(&silence, s.now())
+	return &silence, nil
+}
+
+// ExpireSilence 过期静默，结束时间改为当前时间，未开始的静默结束时间改为开始时间
+// 未开始的静默保留开始时间，维护窗口同步时不会为手动过期的时段重新创建静默
+func (s *AlertSilenceService) ExpireSilence(id uint) (*Models.MonitoringSilence, error) {
+	silence, err := s.GetSilence(id)
+	if err != nil {
+		return nil, err
+	}
+	if silence.Status == SilenceStateExpired {
+		return nil, ErrSilenceExpired
+	}
+
+	endsAt := s.now()
+	if silence.Status == SilenceStatePending {
+		endsAt = silence.StartsAt
+	}
+	if err := s.db.Model(silence).Update("ends_at", endsAt).Error; err != nil {
+		return nil, err
+	}
+	s.refreshAfterChange()
+	return s.GetSilence(id)
+}
+
+// CreateMaintenanceWindow 创建维护窗口并立即创建当前或下一次时段的静默
+func (s *AlertSilenceService) CreateMaintenanceWindow(input MaintenanceWindowInput, userID uint) (*Models.MonitoringMaintenanceWindow, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len([]rune(name)) > 100 {
+		return nil, fmt.Errorf("%w：名称不能为空且不能超过100个字符", ErrInvalidMaintenanceWindow)
+	}
+	if _, err := compileSilenceMatchers(input.Matchers); err != nil {
+		return nil, err
+	}
+
+	window := &Models.MonitoringMaintenanceWindow{
+		Name:        name,
+		Description: input.Description,
+		Matchers:    input.Matchers,
+		CreatedBy:   userID,
+	}
+	if input.Schedule != "" {
+		if _, err := ParseCronSchedule(input.Schedule); err != nil {
+			return nil, fmt.Errorf("%w：%v", ErrInvalidMaintenanceWindow, err)
+		}
+		if input.DurationMinutes <= 0 {
+			return nil, fmt.Errorf("%w：重复窗口的持续时间必须大于0", ErrInvalidMaintenanceWindow)
+		}
+		window.Schedule = input.Schedule
+		window.DurationMinutes = input.DurationMinutes
+	} else {
+		if input.StartsAt == nil || input.EndsAt == nil || !input.EndsAt.After(*input.StartsAt) {
+			return nil, fmt.Errorf("%w：单次窗口需要开始时间和晚于开始时间的结束时间", ErrInvalidMaintenanceWindow)
+		}
+		if !input.EndsAt.After(s.now()) {
+			return nil, fmt.Errorf("%w：结束时间必须晚于当前时间", ErrInvalidMaintenanceWindow)
+		}
+		window.StartsAt = input.StartsAt
+		window.EndsAt = input.EndsAt
+	}
+
+	if err := s.db.Create(window).Error; err != nil {
+		return nil, err
+	}
+	s.syncMu.Lock()
+	err := s.syncWindow(window, s.now())
+	s.syncMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	s.refreshAfterChange()
+	return window, nil
+}
+
+// ListMaintenanceWindows 分页查询维护窗口
+func (s *AlertSilenceService) ListMaintenanceWindows(q *Utils.ListQuery, req Utils.PageRequest) ([]Models.MonitoringMaintenanceWindow, Utils.PageMeta, error) {
+	query, order, err := q.Apply(s.db.Model(&Models.MonitoringMaintenanceWindow{}), req)
+	if err != nil {
+		return nil, Utils.PageMeta{}, err
+	}
+	var windows []Models.MonitoringMaintenanceWindow
+	meta, err := Utils.Paginate(query, req, order, &windows)
+	return windows, meta, err
+}
+
+// GetMaintenanceWindow 获取维护窗口
+func (s *AlertSilenceService) GetMaintenanceWindow(id uint) (*Models.MonitoringMaintenanceWindow, error) {
+	var window Models.MonitoringMaintenanceWindow
+	if err := s.db.First(&window, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMaintenanceWindowNotFound
+		}
+		return nil, err
+	}
+	return &window, nil
+}
+
+// DeleteMaintenanceWindow 删除维护窗口并过期关联的静默
+func (s *AlertSilenceService) DeleteMaintenanceWindow(id uint) error {
+	window, err := s.GetMaintenanceWindow(id)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		linked := tx.Model(&Models.MonitoringSilence{}).Where("maintenance_window_id = ? AND ends_at > ?", window.ID, now).Session(&gorm.Session{})
+		if err := linked.Where("starts_at > ?", now).Update("ends_at", gorm.Expr("starts_at")).Error; err != nil {
+			return err
+		}
+		if err := linked.Where("starts_at <= ?", now).Update("ends_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Delete(window).Error
+	})
+	if err != nil {
+		return err
+	}
+	s.refreshAfterChange()
+	return nil
+}
+
+// SyncMaintenanceWindows 为每个维护窗口的当前或下一次时段创建关联的静默
+func (s *AlertSilenceService) SyncMaintenanceWindows() error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	var windows []Models.MonitoringMaintenanceWindow
+	if err := s.db.Find(&windows).Error; err != nil {
+		return err
+	}
+	now := s.now()
+	for i := range windows {
+		if err := s.syncWindow(&windows[i], now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncWindow 窗口的当前或下一次时段尚未创建静默时创建
+// 最近一次关联静默的开始时间不早于该时段时视为已创建，手动过期的时段不会重新创建
+func (s *AlertSilenceService) syncWindow(window *Models.MonitoringMaintenanceWindow, now time.Time) error {
+	start, end, ok := maintenanceOccurrence(window, now)
+	if !ok {
+		return nil
+	}
+
+	var latest Models.MonitoringSilence
+	err := s.db.Where("maintenance_window_id = ?", window.ID).Order("starts_at DESC").First(&latest).Error
+	if err == nil && !latest.StartsAt.Before(start) {
+		return nil
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	windowID := window.ID
+	silence := &Models.MonitoringSilence{
+		Matchers:            window.Matchers,
+		StartsAt:            start,
+		EndsAt:              end,
+		CreatedBy:           window.CreatedBy,
+		Comment:             "维护窗口: " + window.Name,
+		MaintenanceWindowID: &windowID,
+	}
+	return s.db.Create(silence).Error
+}
+
+// refreshAfterChange 修改静默后刷新缓存，失败只记录日志
+func (s *AlertSilenceService) refreshAfterChange() {
+	if err := s.Refresh(); err != nil {
+		log.Printf("刷新告警静默失败: %v", err)
+	}
+}
+
+// maintenanceOccurrence 维护窗口的当前或下一次时段，窗口已结束时返回 false
+func maintenanceOccurrence(window *Models.MonitoringMaintenanceWindow, now time.Time) (time.Time, time.Time, bool) {
+	if window.Schedule == "" {
+		if window.StartsAt == nil || window.EndsAt == nil || !window.EndsAt.After(now) {
+			return time.Time{}, time.Time{}, false
+		}
+		return *window.StartsAt, *window.EndsAt, true
+	}
+
+	schedule, err := ParseCronSchedule(window.Schedule)
+	if err != nil || window.DurationMinutes <= 0 {
+		return time.Time{}, time.Time{}, false
+	}
+	duration := time.Duration(window.DurationMinutes) * time.Minute
+	start := schedule.Next(now.Add(-duration))
+	if start.IsZero() {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, start.Add(duration), true
+}
+
+// silenceState 按当前时间计算静默状态
+func silenceState(silence *Models.MonitoringSilence, now time.Time) string {
+	switch {
+	case !now.Before(silence.EndsAt) || !silence.EndsAt.After(silence.StartsAt):
+		return SilenceStateExpired
+	case now.Before(silence.StartsAt):
+		return SilenceStatePending
+	default:
+		return SilenceStateActive
+	}
+}
+
+// compileSilenceMatchers 校验并编译匹配器
+// 与 Alertmanager 相同，至少一个匹配器不能匹配空值，避免静默全部告警
+func compileSilenceMatchers(matchers []Models.SilenceMatcher) ([]silenceMatcher, error) {
+	if len(matchers) == 0 || len(matchers) > maxSilenceMatchers {
+		return nil, fmt.Errorf("%w：匹配器数量必须在1到%d之间", ErrInvalidSilence, maxSilenceMatchers)
+	}
+
+	compiled := make([]silenceMatcher, 0, len(matchers))
+	matchesEmpty := true
+	for _, matcher := range matchers {
+		if !ingestLabelNamePattern.MatchString(matcher.Name) {
+			return nil, fmt.Errorf("%w：标签名称 %q 不合法", ErrInvalidSilence, matcher.Name)
+		}
+		entry := silenceMatcher{SilenceMatcher: matcher}
+		if matcher.IsRegex {
+			regex, err := regexp.Compile("^(?:" + matcher.Value + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("%w：标签 %s 的正则表达式无效: %v", ErrInvalidSilence, matcher.Name, err)
+			}
+			entry.regex = regex
+		}
+		if !entry.matches(map[string]string{}) {
+			matchesEmpty = false
+		}
+		compiled = append(compiled, entry)
+	}
+	if matchesEmpty {
+		return nil, fmt.Errorf("%w：至少一个匹配器不能匹配空值", ErrInvalidSilence)
+	}
+	return compiled, nil
+}
@@ -0,0 +1,361 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrAlertWebhookUnauthorized 告警推送令牌无效
+	ErrAlertWebhookUnauthorized = errors.New("告警推送令牌无效")
+	// ErrAlertWebhookTooManyAlerts 单次推送的告警数超过限制
+	ErrAlertWebhookTooManyAlerts = errors.New("推送的告警过多")
+)
+
+// maxAlertWebhookErrors 推送结果中返回的拒绝原因条数上限
+const maxAlertWebhookErrors = 20
+
+// maxExternalAlertNameLength 外部告警名称的长度上限，规则ID加上来源后需在事件表的规则字段长度内
+const maxExternalAlertNameLength = 64
+
+// AlertWebhookResult 告警推送结果
+type AlertWebhookResult struct {
+	Source    string   `json:"source"`
+	Triggered int      `json:"triggered"` // 新触发的告警数
+	Repeated  int      `json:"repeated"`  // 已触发告警的重复推送数
+	Resolved  int      `json:"resolved"`  // 解决的告警数
+	Ignored   int      `json:"ignored"`   // 恢复未知告警的推送数
+	Rejected  int      `json:"rejected"`
+	Errors    []string `json:"errors,omitempty"` // 最多返回前 maxAlertWebhookErrors 条拒绝原因
+}
+
+// alertmanagerPayload Alertmanager webhook 请求体（version 4）
+type alertmanagerPayload struct {
+	Version     string              `json:"version"`
+	Status      string              `json:"status"`
+	Receiver    string              `json:"receiver"`
+	ExternalURL string              `json:"externalURL"`
+	Alerts      []alertmanagerAlert `json:"alerts"`
+}
+
+// alertmanagerAlert Alertmanager webhook 中的单个告警
+type alertmanagerAlert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// AlertWebhookService 外部告警接入服务
+// 功能说明：
+// 1. 按来源令牌认证推送方，令牌在 MONITORING_ALERT_WEBHOOK_TOKENS 中按 来源:令牌 配置
+// 2. 支持 Prometheus Alertmanager webhook 格式，告警名称取 alertname 标签，内容取 summary 或 description 注解
+// 3. 支持通用JSON格式，按 MONITORING_ALERT_WEBHOOK_MAPPING_* 中的字段路径取告警名称、状态、严重程度、内容、标签和时间
+// 4. 接收的告警交给监控核心的告警引擎，与规则告警一样按指纹去重、发送通知、归入事件并推送到实时事件流
+// 5. 缺少告警名称、名称过长或标签名称不合法的告警逐条拒绝，其余告警正常接收
+type AlertWebhookService struct {
+	config  *Config.MonitoringConfig
+	sources map[string]string // 令牌 -> 来源
+	core    *MonitoringCore
+}
+
+// NewAlertWebhookService 创建外部告警接入服务
+func NewAlertWebhookService(config *Config.MonitoringConfig) *AlertWebhookService {
+	if config == nil {
+		config = &Config.MonitoringConfig{}
+		config.SetDefaults()
+		if globalConfig := Config.GetConfig(); globalConfig != nil {
+			config = &globalConfig.Monitoring
+		}
+	}
+
+	sources := make(map[string]string)
+	for _, entry := range splitNotificationList(config.AlertWebhook.Tokens) {
+		source, token, found := strings.Cut(entry, ":")
+		if found && strings.TrimSpace(source) != "" && strings.TrimSpace(token) != "" {
+			sources[strings.TrimSpace(token)] = strings.TrimSpace(source)
+		}
+	}
+
+	return &AlertWebhookService{
+		config:  config,
+		sources: sources,
+		core:    DefaultMonitoringCore(),
+	}
+}
+
+// SetMonitoringCore 设置接收告警的监控核心
+func (s *AlertWebhookService) SetMonitoringCore(core *MonitoringCore) {
+	s.core = core
+}
+
+// MaxBodySize 单次请求体最大字节数
+func (s *AlertWebhookService) MaxBodySize() int64 {
+	return s.config.AlertWebhook.MaxBodySize
+}
+
+// Authenticate 校验推送令牌，返回令牌对应的来源
+func (s *AlertWebhookService) Authenticate(token string) (string, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", ErrAlertWebhookUnauthorized
+	}
+	// 逐个常量时间比较，避免通过响应时间猜测令牌
+	matched := ""
+	for candidate, source := range s.sources {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+			matched = source
+		}
+	}
+	if matched == "" {
+		return "", ErrAlertWebhookUnauthorized
+	}
+	return matched, nil
+}
+
+// ParseAlertmanager 解析 Alertmanager webhook 请求体
+func (s *AlertWebhookService) ParseAlertmanager(body []byte) ([]ExternalAlert, error) {
+	var payload alertmanagerPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("解析Alertmanager请求体失败: %w", err)
+	}
+	if payload.Version != "" && payload.Version != "4" {
+		return nil, fmt.Errorf("不支持的Alertmanager webhook版本 %q", payload.Version)
+	}
+
+	alerts := make([]ExternalAlert, 0, len(payload.Alerts))
+	for _, item := range payload.Alerts {
+		annotations := make(map[string]string, len(item.Annotations)+1)
+		for key, value := range item.Annotations {
+			annotations[key] = value
+		}
+		if item.GeneratorURL != "" {
+			annotations["generator_url"] = item.GeneratorURL
+		}
+
+		name := item.Labels["alertname"]
+		message := item.Annotations["summary"]
+		if message == "" {
+			message = item.Annotations["description"]
+		}
+		if message == "" {
+			message = name
+		}
+		alerts = append(alerts, ExternalAlert{
+			Name:        name,
+			Resolved:    item.Status == "resolved",
+			Level:       s.level(item.Labels["severity"]),
+			Message:     message,
+			Labels:      item.Labels,
+			Annotations: annotations,
+			StartsAt:    item.StartsAt,
+			EndsAt:      item.EndsAt,
+		})
+	}
+	return alerts, nil
+}
+
+// ParseJSON 按配置的字段路径解析通用JSON请求体
+// 请求体可以是单个告警、告警数组，或按 MONITORING_ALERT_WEBHOOK_MAPPING_ALERTS 指定路径包含告警数组的对象
+func (s *AlertWebhookService) ParseJSON(body []byte) ([]ExternalAlert, error) {
+	var payload interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("解析JSON请求体失败: %w", err)
+	}
+
+	mapping := s.config.AlertWebhook.Mapping
+	if mapping.Alerts != "" {
+		value, found := jsonPathValue(payload, mapping.Alerts)
+		if !found {
+			return nil, fmt.Errorf("请求体中没有告警字段 %s", mapping.Alerts)
+		}
+		payload = value
+	}
+
+	var items []interface{}
+	switch value := payload.(type) {
+	case []interface{}:
+		items = value
+	case map[string]interface{}:
+		items = []interface{}{value}
+	default:
+		return nil, fmt.Errorf("告警必须是JSON对象或数组")
+	}
+
+	resolvedValues := make(map[string]bool)
+	for _, value := range splitNotificationList(mapping.ResolvedValues) {
+		resolvedValues[strings.ToLower(value)] = true
+	}
+
+	alerts := make([]ExternalAlert, 0, len(items))
+	for _, item := range items {
+		alert := ExternalAlert{
+			Name:     jsonPathString(item, mapping.Name),
+			Resolved: resolvedValues[strings.ToLower(jsonPathString(item, mapping.Status))],
+			Level:    s.level(jsonPathString(item, mapping.Severity)),
+			Message:  jsonPathString(item, mapping.Message),
+			StartsAt: jsonPathTime(item, mapping.StartsAt),
+			EndsAt:   jsonPathTime(item, mapping.EndsAt),
+		}
+		if labels, ok := jsonPathObject(item, mapping.Labels); ok {
+			alert.Labels = make(map[string]string, len(labels)+1)
+			for key, value := range labels {
+				alert.Labels[key] = jsonScalarString(value)
+			}
+		}
+		// 与 Alertmanager 一致，告警名称作为 alertname 标签，便于按标签匹配
+		if alert.Name != "" {
+			if alert.Labels == nil {
+				alert.Labels = make(map[string]string, 1)
+			}
+			if _, exists := alert.Labels["alertname"]; !exists {
+				alert.Labels["alertname"] = alert.Name
+			}
+		}
+		if alert.Message == "" {
+			alert.Message = alert.Name
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, nil
+}
+
+// Receive 校验并接收来源推送的告警
+func (s *AlertWebhookService) Receive(source string, alerts []ExternalAlert) (*AlertWebhookResult, error) {
+	if len(alerts) > s.config.AlertWebhook.MaxAlerts {
+		return nil, fmt.Errorf("%w：%d 个告警，上限 %d", ErrAlertWebhookTooManyAlerts, len(alerts), s.config.AlertWebhook.MaxAlerts)
+	}
+
+	result := &AlertWebhookResult{Source: source}
+	for i, alert := range alerts {
+		if err := validateExternalAlert(alert); err != nil {
+			result.Rejected++
+			if len(result.Errors) < maxAlertWebhookErrors {
+				result.Errors = append(result.Errors, fmt.Sprintf("第 %d 个告警 %s: %v", i+1, alert.Name, err))
+			}
+			continue
+		}
+
+		alert.Source = source
+		received, transition := s.core.ReceiveExternalAlert(alert)
+		switch {
+		case transition == AlertTransitionTriggered:
+			result.Triggered++
+		case transition == AlertTransitionResolved:
+			result.Resolved++
+		case received != nil:
+			result.Repeated++
+		default:
+			result.Ignored++
+		}
+	}
+	return result, nil
+}
+
+// level 把外部系统的严重程度映射为告警级别，无法识别时使用默认级别
+func (s *AlertWebhookService) level(severity string) AlertLevel {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "critical", "fatal", "page", "p1", "high":
+		return AlertLevelCritical
+	case "error", "major", "p2":
+		return AlertLevelError
+	case "warning", "warn", "minor", "medium", "p3":
+		return AlertLevelWarning
+	case "info", "informational", "low", "none", "p4", "p5":
+		return AlertLevelInfo
+	}
+	return AlertLevel(s.config.AlertWebhook.DefaultLevel)
+}
+
+// validateExternalAlert 校验外部告警的名称和标签
+func validateExternalAlert(alert ExternalAlert) error {
+	if strings.TrimSpace(alert.Name) == "" {
+		return fmt.Errorf("缺少告警名称")
+	}
+	if len(alert.Name) > maxExternalAlertNameLength {
+		return fmt.Errorf("告警名称超过 %d 个字符", maxExternalAlertNameLength)
+	}
+	for name := range alert.Labels {
+		if !ingestLabelNamePattern.MatchString(name) {
+			return fmt.Errorf("标签名称 %q 不合法", name)
+		}
+	}
+	return nil
+}
+
+// jsonPathValue 按 . 分隔的路径读取JSON值
+func jsonPathValue(value interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return nil, false
+	}
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// jsonPathString 按路径读取字符串，数值和布尔值转换为字符串
+func jsonPathString(value interface{}, path string) string {
+	if value, found := jsonPathValue(value, path); found {
+		return jsonScalarString(value)
+	}
+	return ""
+}
+
+// jsonPathObject 按路径读取JSON对象
+func jsonPathObject(value interface{}, path string) (map[string]interface{}, bool) {
+	value, found := jsonPathValue(value, path)
+	if !found {
+		return nil, false
+	}
+	object, ok := value.(map[string]interface{})
+	return object, ok
+}
+
+// jsonPathTime 按路径读取时间，支持RFC3339字符串和Unix秒，无法解析时返回零值
+func jsonPathTime(value interface{}, path string) time.Time {
+	value, found := jsonPathValue(value, path)
+	if !found {
+		return time.Time{}
+	}
+	switch v := value.(type) {
+	case string:
+		if at, err := time.Parse(time.RFC3339, v); err == nil {
+			return at
+		}
+		if seconds, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.Unix(seconds, 0)
+		}
+	case float64:
+		return time.Unix(int64(v), 0)
+	}
+	return time.Time{}
+}
+
+// jsonScalarString JSON标量转换为字符串，对象和数组返回空字符串
+func jsonScalarString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
@@ -0,0 +1,292 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AnomalyObservation 一次待检测的用户行为
+type AnomalyObservation struct {
+	UserID    uint      `json:"user_id"`
+	EventType string    `json:"event_type"`
+	Resource  string    `json:"resource"`
+	Action    string    `json:"action"`
+	IPAddress string    `json:"ip_address"`
+	Time      time.Time `json:"time"`
+}
+
+// AnomalyModel 异常检测模型接口
+//
+// 模型按用户学习行为基线：Observe 用于训练，Score 返回 0-1 的异常分数；
+// 用户仍在学习期或数据不足时 Score 返回 ready=false，调用方应忽略分数。
+// Save/Load 用于持久化模型状态，重启后无需重新学习。
+type AnomalyModel interface {
+	Name() string
+	Observe(observation AnomalyObservation)
+	Score(observation AnomalyObservation) (score float64, ready bool)
+	Save(w io.Writer) error
+	Load(r io.Reader) error
+}
+
+// AnomalyModelFactory 异常检测模型构造函数
+type AnomalyModelFactory func(config *Config.AnomalyDetectionConfig) AnomalyModel
+
+var (
+	anomalyModelsMu sync.RWMutex
+	anomalyModels   = map[string]AnomalyModelFactory{
+		StatisticalAnomalyModelName: func(config *Config.AnomalyDetectionConfig) AnomalyModel {
+			return NewStatisticalAnomalyModel(config)
+		},
+	}
+)
+
+// RegisterAnomalyModel 注册异常检测模型，名称与 MLModelType 配置对应
+func RegisterAnomalyModel(name string, factory AnomalyModelFactory) {
+	anomalyModelsMu.Lock()
+	defer anomalyModelsMu.Unlock()
+	anomalyModels[name] = factory
+}
+
+// NewAnomalyModel 按配置的模型类型创建异常检测模型
+func NewAnomalyModel(config *Config.AnomalyDetectionConfig) (AnomalyModel, error) {
+	anomalyModelsMu.RLock()
+	factory, ok := anomalyModels[config.MLModelType]
+	anomalyModelsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("未注册的异常检测模型: %s", config.MLModelType)
+	}
+	return factory(config), nil
+}
+
+// StatisticalAnomalyModelName 统计基线模型名称
+const StatisticalAnomalyModelName = "statistical"
+
+// 统计基线模型参数
+const (
+	statBucketSize      = time.Minute // 按分钟统计请求数
+	statWindowBuckets   = 60          // 滚动均值和标准差的窗口（最近60分钟）
+	statMinBuckets      = 10          // 开始评分前至少需要的完整分钟数
+	statEWMAAlpha       = 0.1         // EWMA平滑系数
+	statSeasonalAlpha   = 0.05        // 季节分量（按小时）的平滑系数
+	statMaxGapBuckets   = 24 * 60     // 长时间无请求时最多补齐一天的空分钟
+	statZThreshold      = 3.0         // Z分数达到该值时突发分数为0.5
+	statMinRarityEvents = 100         // 计算时段罕见度前至少需要的事件数
+	statRarityWeight    = 0.8         // 从未活跃的时段最多贡献的分数
+)
+
+// userBaseline 单个用户的行为基线
+type userBaseline struct {
+	FirstSeen   time.Time   `json:"first_seen"`
+	BucketStart time.Time   `json:"bucket_start"`
+	Current     float64     `json:"current"`      // 当前分钟的请求数
+	Window      []float64   `json:"window"`       // 最近完成的分钟请求数
+	EWMA        float64     `json:"ewma"`         // 指数加权均值
+	EWMVar      float64     `json:"ewm_var"`      // 指数加权方差
+	Seasonal    [24]float64 `json:"seasonal"`     // 每小时的平均每分钟请求数
+	SeasonalVar [24]float64 `json:"seasonal_var"` // 去除季节分量后的残差方差
+	HourCounts  [24]float64 `json:"hour_counts"`  // 每小时的累计事件数
+	Events      float64     `json:"events"`
+	Buckets     int         `json:"buckets"` // 已完成的分钟数
+}
+
+// StatisticalAnomalyModel 统计基线异常检测模型
+// 功能说明：
+// 1. 按用户统计每分钟请求数，维护滚动均值/标准差、EWMA和按小时的季节分量
+// 2. 当前分钟请求数相对三种基线的Z分数取最大值，映射为0-1的突发分数
+// 3. 统计用户在一天中各小时的活跃分布，在很少活跃的时段访问时给出罕见度分数
+// 4. 学习模式下，用户首次出现后的 LearningPeriod 内只学习不评分
+type StatisticalAnomalyModel struct {
+	learningPeriod time.Duration
+	users          map[uint]*userBaseline
+	mu             sync.Mutex
+}
+
+// NewStatisticalAnomalyModel 创建统计基线模型
+func NewStatisticalAnomalyModel(config *Config.AnomalyDetectionConfig) *StatisticalAnomalyModel {
+	model := &StatisticalAnomalyModel{users: make(map[uint]*userBaseline)}
+	if config != nil && config.LearningMode {
+		model.learningPeriod = config.LearningPeriod
+	}
+	return model
+}
+
+// Name 模型名称
+func (m *StatisticalAnomalyModel) Name() string {
+	return StatisticalAnomalyModelName
+}
+
+// Observe 记录一次用户行为
+func (m *StatisticalAnomalyModel) Observe(observation AnomalyObservation) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[observation.UserID]
+	if !ok {
+		user = &userBaseline{
+			FirstSeen:   observation.Time,
+			BucketStart: observation.Time.Truncate(statBucketSize),
+		}
+		m.users[observation.UserID] = user
+	}
+
+	user.advance(observation.Time)
+	user.Current++
+	user.Events++
+	user.HourCounts[observation.Time.Hour()]++
+}
+
+// advance 将时间推进到 t 所在的分钟，完成之前的分钟并补齐中间没有请求的分钟
+func (u *userBaseline) advance(t time.Time) {
+	bucket := t.Truncate(statBucketSize)
+	if !bucket.After(u.BucketStart) {
+		return
+	}
+
+	gap := int(bucket.Sub(u.BucketStart) / statBucketSize)
+	if gap > statMaxGapBuckets {
+		// 只补齐最近一天的空分钟，更早的空闲期对基线没有意义
+		u.BucketStart = bucket.Add(-statMaxGapBuckets * statBucketSize)
+		u.Current = 0
+		gap = statMaxGapBuckets
+	}
+	for i := 0; i < gap; i++ {
+		u.complete(u.BucketStart, u.Current)
+		u.BucketStart = u.BucketStart.Add(statBucketSize)
+		u.Current = 0
+	}
+}
+
+// complete 用一个完成的分钟更新基线
+func (u *userBaseline) complete(start time.Time, count float64) {
+	u.Window = append(u.Window, count)
+	if len(u.Window) > statWindowBuckets {
+		u.Window = u.Window[len(u.Window)-statWindowBuckets:]
+	}
+
+	if u.Buckets == 0 {
+		u.EWMA = count
+	} else {
+		diff := count - u.EWMA
+		u.EWMA += statEWMAAlpha * diff
+		u.EWMVar = (1 - statEWMAAlpha) * (u.EWMVar + statEWMAAlpha*diff*diff)
+	}
+
+	hour := start.Hour()
+	residual := count - u.Seasonal[hour]
+	u.Seasonal[hour] += statSeasonalAlpha * residual
+	u.SeasonalVar[hour] = (1 - statSeasonalAlpha) * (u.SeasonalVar[hour] + statSeasonalAlpha*residual*residual)
+
+	u.Buckets++
+}
+
+// Score 计算异常分数
+func (m *StatisticalAnomalyModel) Score(observation AnomalyObservation) (float64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[observation.UserID]
+	if !ok || observation.Time.Sub(user.FirstSeen) < m.learningPeriod {
+		return 0, false
+	}
+	user.advance(observation.Time)
+	if user.Buckets < statMinBuckets {
+		return 0, false
+	}
+
+	mean, std := meanStd(user.Window)
+	hour := observation.Time.Hour()
+	z := math.Max(zScore(user.Current, mean, std), zScore(user.Current, user.EWMA, math.Sqrt(user.EWMVar)))
+	z = math.Max(z, zScore(user.Current, user.Seasonal[hour], math.Sqrt(user.SeasonalVar[hour])))
+	score := 1 / (1 + math.Exp(statZThreshold-z))
+	if z <= 0 {
+		score = 0
+	}
+
+	// 时段罕见度：该小时的活跃占比低于平均水平（1/24）时按比例计分
+	if user.Events >= statMinRarityEvents {
+		share := user.HourCounts[hour] / user.Events
+		rarity := math.Max(0, 1-share*24) * statRarityWeight
+		score = math.Max(score, rarity)
+	}
+
+	return score, true
+}
+
+// zScore 计算相对基线的Z分数，标准差至少按1计算，避免请求数很少时分数过于敏感
+func zScore(value, mean, std float64) float64 {
+	return (value - mean) / math.Max(std, 1)
+}
+
+// meanStd 计算均值和标准差
+func meanStd(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
+
+// statisticalModelState 持久化格式
+type statisticalModelState struct {
+	Model   string                 `json:"model"`
+	SavedAt time.Time              `json:"saved_at"`
+	Users   map[uint]*userBaseline `json:"users"`
+}
+
+// Save 保存模型状态
+func (m *StatisticalAnomalyModel) Save(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return json.NewEncoder(w).Encode(statisticalModelState{
+		Model:   StatisticalAnomalyModelName,
+		SavedAt: time.Now(),
+		Users:   m.users,
+	})
+}
+
+// Load 加载模型状态，替换当前状态
+func (m *StatisticalAnomalyModel) Load(r io.Reader) error {
+	var state statisticalModelState
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return fmt.Errorf("解析模型状态失败: %v", err)
+	}
+	if state.Model != StatisticalAnomalyModelName {
+		return fmt.Errorf("模型类型不匹配: %s", state.Model)
+	}
+	if state.Users == nil {
+		state.Users = make(map[uint]*userBaseline)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.users = state.Users
+	return nil
+}
+
+// Users 获取已学习的用户ID
+func (m *StatisticalAnomalyModel) Users() []uint {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	users := make([]uint, 0, len(m.users))
+	for id := range m.users {
+		users = append(users, id)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i] < users[j] })
+	return users
+}
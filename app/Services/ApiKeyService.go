@@ -8,6 +8,8 @@ import (
 	"encoding/hex"
 	"fmt"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // ApiKeyService API密钥管理服务
@@ -42,6 +44,21 @@ func NewApiKeyService() *ApiKeyService {
 
 // CreateApiKey 创建API密钥
 func (s *ApiKeyService) CreateApiKey(userID uint, name string, permissions *Models.ApiKeyPermission, description string, expiresAt *time.Time) (*Models.ApiKey, string, error) {
+	return s.createApiKey(userID, nil, name, permissions, description, expiresAt)
+}
+
+// CreateTeamApiKey 创建归属团队的API密钥，只有团队成员可以创建，团队成员都可以查看和管理
+//
+// 密钥仍以创建者身份调用接口，创建者离开团队后其他成员可以删除或重新生成密钥。
+func (s *ApiKeyService) CreateTeamApiKey(userID, teamID uint, name string, permissions *Models.ApiKeyPermission, description string, expiresAt *time.Time) (*Models.ApiKey, string, error) {
+	if _, ok := teamMemberships(Database.DB, userID)[teamID]; !ok {
+		return nil, "", ErrTeamForbidden
+	}
+	return s.createApiKey(userID, &teamID, name, permissions, description, expiresAt)
+}
+
+// createApiKey 创建API密钥，teamID 不为空时密钥名称在团队内唯一
+func (s *ApiKeyService) createApiKey(userID uint, teamID *uint, name string, permissions *Models.ApiKeyPermission, description string, expiresAt *time.Time) (*Models.ApiKey, string, error) {
 	// 检查用户是否存在
 	var user Models.User
 	if err := Database.DB.First(&user, userID).Error; err != nil {
@@ -50,7 +67,11 @@ func (s *ApiKeyService) CreateApiKey(userID uint, name string, permissions *Mode
 	
 	// 检查密钥名称是否重复
 	var existingKey Models.ApiKey
-	if err := Database.DB.Where("user_id = ? AND name = ?", userID, name).First(&existingKey).Error; err == nil {
+	nameQuery := Database.DB.Where("user_id = ? AND team_id IS NULL AND name = ?", userID, name)
+	if teamID != nil {
+		nameQuery = Database.DB.Where("team_id = ? AND name = ?", *teamID, name)
+	}
+	if err := nameQuery.First(&existingKey).Error; err == nil {
 		return nil, "", fmt.Errorf("密钥名称已存在")
 	}
 	
@@ -59,6 +80,7 @@ func (s *ApiKeyService) CreateApiKey(userID uint, name string, permissions *Mode
 	if err != nil {
 		return nil, "", fmt.Errorf("创建API密钥失败: %v", err)
 	}
+	apiKey.TeamID = teamID
 	
 	// 设置过期时间
 	if expiresAt != nil {
@@ -74,6 +96,7 @@ func (s *ApiKeyService) CreateApiKey(userID uint, name string, permissions *Mode
 	s.logAudit("create_api_key", userID, "创建API密钥", map[string]interface{}{
 		"api_key_id": apiKey.ID,
 		"name":       name,
+		"team_id":    teamID,
 		"expires_at": expiresAt,
 	})
 	
@@ -112,19 +135,19 @@ func (s *ApiKeyService) ValidateApiKey(key string, resource, method string) (*Mo
 	return &apiKey, nil
 }
 
-// GetApiKeys 获取用户的API密钥列表
+// GetApiKeys 获取用户的API密钥列表，包括用户所在团队的密钥
 func (s *ApiKeyService) GetApiKeys(userID uint, page, limit int) ([]Models.ApiKey, int64, error) {
 	var apiKeys []Models.ApiKey
 	var total int64
 	
 	// 计算总数
-	if err := Database.DB.Model(&Models.ApiKey{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+	if err := s.accessibleKeys(userID).Model(&Models.ApiKey{}).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 	
 	// 分页查询
 	offset := (page - 1) * limit
-	if err := Database.DB.Where("user_id = ?", userID).
+	if err := s.accessibleKeys(userID).
 		Order("created_at DESC").
 		Offset(offset).Limit(limit).
 		Find(&apiKeys).Error; err != nil {
@@ -134,10 +157,10 @@ func (s *ApiKeyService) GetApiKeys(userID uint, page, limit int) ([]Models.ApiKe
 	return apiKeys, total, nil
 }
 
-// GetApiKey 获取单个API密钥
+// GetApiKey 获取单个API密钥，用户自己的密钥和所在团队的密钥都可以获取
 func (s *ApiKeyService) GetApiKey(userID, apiKeyID uint) (*Models.ApiKey, error) {
 	var apiKey Models.ApiKey
-	if err := Database.DB.Where("id = ? AND user_id = ?", apiKeyID, userID).First(&apiKey).Error; err != nil {
+	if err := s.accessibleKeys(userID).Where("id = ?", apiKeyID).First(&apiKey).Error; err != nil {
 		return nil, fmt.Errorf("API密钥不存在")
 	}
 	
@@ -148,7 +171,7 @@ func (s *ApiKeyService) GetApiKey(userID, apiKeyID uint) (*Models.ApiKey, error)
 func (s *ApiKeyService) UpdateApiKey(userID, apiKeyID uint, updates map[string]interface{}) error {
 	// 检查API密钥是否存在
 	var apiKey Models.ApiKey
-	if err := Database.DB.Where("id = ? AND user_id = ?", apiKeyID, userID).First(&apiKey).Error; err != nil {
+	if err := s.accessibleKeys(userID).Where("id = ?", apiKeyID).First(&apiKey).Error; err != nil {
 		return fmt.Errorf("API密钥不存在")
 	}
 	
@@ -170,12 +193,12 @@ func (s *ApiKeyService) UpdateApiKey(userID, apiKeyID uint, updates map[string]i
 func (s *ApiKeyService) DeleteApiKey(userID, apiKeyID uint) error {
 	// 检查API密钥是否存在
 	var apiKey Models.ApiKey
-	if err := Database.DB.Where("id = ? AND user_id = ?", apiKeyID, userID).First(&apiKey).Error; err != nil {
+	if err := s.accessibleKeys(userID).Where("id = ?", apiKeyID).First(&apiKey).Error; err != nil {
 		return fmt.Errorf("API密钥不存在")
 	}
 	
-	// 软删除
-	if err := Database.DB.Delete(&apiKey).Error; err != nil {
+	// 软删除，密钥和使用记录进入回收站
+	if err := NewDeletionService(Database.DB, nil).Delete(TrashApiKeys, apiKey.ID); err != nil {
 		return fmt.Errorf("删除API密钥失败: %v", err)
 	}
 	
@@ -192,7 +215,7 @@ func (s *ApiKeyService) DeleteApiKey(userID, apiKeyID uint) error {
 func (s *ApiKeyService) RegenerateApiKey(userID, apiKeyID uint) (*Models.ApiKey, string, error) {
 	// 检查API密钥是否存在
 	var apiKey Models.ApiKey
-	if err := Database.DB.Where("id = ? AND user_id = ?", apiKeyID, userID).First(&apiKey).Error; err != nil {
+	if err := s.accessibleKeys(userID).Where("id = ?", apiKeyID).First(&apiKey).Error; err != nil {
 		return nil, "", fmt.Errorf("API密钥不存在")
 	}
 	
@@ -224,16 +247,21 @@ func (s *ApiKeyService) GetApiKeyUsage(userID, apiKeyID uint, page, limit int) (
 	var usages []Models.ApiKeyUsage
 	var total int64
 	
+	// 团队密钥的使用记录属于创建者，团队成员也可以查看
+	if _, err := s.GetApiKey(userID, apiKeyID); err != nil {
+		return nil, 0, err
+	}
+	
 	// 计算总数
 	if err := Database.DB.Model(&Models.ApiKeyUsage{}).
-		Where("api_key_id = ? AND user_id = ?", apiKeyID, userID).
+		Where("api_key_id = ?", apiKeyID).
 		Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 	
 	// 分页查询
 	offset := (page - 1) * limit
-	if err := Database.DB.Where("api_key_id = ? AND user_id = ?", apiKeyID, userID).
+	if err := Database.DB.Where("api_key_id = ?", apiKeyID).
 		Order("created_at DESC").
 		Offset(offset).Limit(limit).
 		Find(&usages).Error; err != nil {
@@ -292,6 +320,15 @@ func (s *ApiKeyService) GetApiKeyStats(userID uint) (map[string]interface{}, err
 
 // 辅助方法
 
+// accessibleKeys 用户可以管理的API密钥：自己创建的密钥和所在团队的密钥
+func (s *ApiKeyService) accessibleKeys(userID uint) *gorm.DB {
+	teamIDs := teamIDList(teamMemberships(Database.DB, userID))
+	if len(teamIDs) == 0 {
+		return Database.DB.Where("user_id = ?", userID)
+	}
+	return Database.DB.Where("user_id = ? OR team_id IN ?", userID, teamIDs)
+}
+
 // hashKey 哈希密钥
 func (s *ApiKeyService) hashKey(key string) string {
 	hash := sha256.Sum256([]byte(key))
@@ -307,9 +344,13 @@ func (s *ApiKeyService) generateRandomKey() (string, error) {
 	return hex.EncodeToString(keyBytes), nil
 }
 
-// logAudit 记录审计日志
+// logAudit 记录审计日志，资源类型为 api_key，用户活动时间线据此展示API密钥操作
 func (s *ApiKeyService) logAudit(action string, userID uint, message string, fields map[string]interface{}) {
-	// 这里应该调用审计服务
-	// 暂时只打印日志
-	fmt.Printf("AUDIT: %s - User: %d - %s - %+v\n", action, userID, message, fields)
+	apiKeyID, _ := fields["api_key_id"].(uint)
+	entry := Models.NewAuditLog(userID, "", action, "api_key", apiKeyID).
+		SetDescription(message).
+		SetMetadata(fields)
+	if Database.DB == nil || Database.DB.Create(entry).Error != nil {
+		fmt.Printf("AUDIT: %s - User: %d - %s - %+v\n", action, userID, message, fields)
+	}
 }
@@ -0,0 +1,346 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Utils"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrApprovalNotFound 审批记录不存在
+	ErrApprovalNotFound = errors.New("审批记录不存在")
+	// ErrApprovalNotPending 审批记录不是待审批状态
+	ErrApprovalNotPending = errors.New("审批记录不是待审批状态")
+	// ErrApprovalNotApproved 审批记录未通过或已执行
+	ErrApprovalNotApproved = errors.New("审批记录未通过或已执行")
+	// ErrApprovalExpired 审批或执行期限已过
+	ErrApprovalExpired = errors.New("审批已过期")
+	// ErrApprovalSelfReview 发起人不能审批自己的操作
+	ErrApprovalSelfReview = errors.New("不能审批自己发起的操作，需要另一名管理员审批")
+	// ErrApprovalMismatch 执行的请求与审批的请求不一致
+	ErrApprovalMismatch = errors.New("请求与审批记录不一致")
+)
+
+// ApprovalRequest 发起审批的请求信息
+type ApprovalRequest struct {
+	Action        string
+	Method        string
+	Path          string
+	Payload       []byte
+	Reason        string
+	RequestedBy   uint
+	RequesterName string
+	IPAddress     string
+	RequestID     string
+}
+
+// ApprovalService 敏感操作双人审批服务
+// 功能说明：
+// 1. 配置的敏感操作由管理员发起时创建待审批记录并通知管理员，记录请求的方法、路径和请求体
+// 2. 另一名管理员在审批期限内通过或拒绝，发起人不能审批自己的操作；超过期限的记录标记为过期
+// 3. 审批通过后发起人在执行期限内重新提交相同的请求，以条件更新占用审批记录，保证只执行一次
+// 4. 发起、审批、拒绝和执行都写入审计日志
+type ApprovalService struct {
+	db      *gorm.DB
+	config  *Config.ApprovalConfig
+	actions map[string]bool
+	now     func() time.Time
+}
+
+// NewApprovalService 创建双人审批服务
+//
+// config 为 nil 时使用全局配置，未加载配置时不启用审批。
+func NewApprovalService(db *gorm.DB, config *Config.ApprovalConfig) *ApprovalService {
+	if config == nil {
+		config = Config.GetApprovalConfig()
+	}
+	if config == nil {
+		config = &Config.ApprovalConfig{TTL: time.Hour, ExecutionTTL: 15 * time.Minute}
+	}
+	actions := make(map[string]bool)
+	for _, action := range config.ActionList() {
+		actions[action] = true
+	}
+	return &ApprovalService{db: db, config: config, actions: actions, now: time.Now}
+}
+
+// SetClock 设置时钟，用于测试
+func (s *ApprovalService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// Requires 操作是否需要审批
+func (s *ApprovalService) Requires(action string) bool {
+	return s.config.Enabled && s.actions[action]
+}
+
+// Request 创建待审批记录并通知管理员
+func (s *ApprovalService) Request(req ApprovalRequest) (*Models.AdminApproval, error) {
+	approval := &Models.AdminApproval{
+		Action:        req.Action,
+		Method:        req.Method,
+		Path:          req.Path,
+		Payload:       string(req.Payload),
+		PayloadHash:   approvalPayloadHash(req.Payload),
+		Reason:        req.Reason,
+		Status:        Models.ApprovalStatusPending,
+		RequestedBy:   req.RequestedBy,
+		RequesterName: req.RequesterName,
+		IPAddress:     req.IPAddress,
+		ExpiresAt:     s.now().Add(s.config.TTL),
+	}
+	if err := s.db.Create(approval).Error; err != nil {
+		return nil, fmt.Errorf("保存审批记录失败: %w", err)
+	}
+
+	s.audit(Models.NewAuditLog(req.RequestedBy, req.RequesterName, Models.AuditActionApprovalRequest, "approval", approval.ID).
+		SetLevel(Models.AuditLevelWarning).
+		SetIPAddress(req.IPAddress).
+		SetRequestID(req.RequestID).
+		SetDescription(fmt.Sprintf("管理员 %s 发起需要审批的操作 %s：%s %s，原因：%s",
+			req.RequesterName, req.Action, req.Method, req.Path, req.Reason)).
+		SetMetadata(approvalMetadata(approval)))
+	s.notify(approval, "有敏感操作等待审批",
+		fmt.Sprintf("管理员 %s 发起了需要审批的操作 %s（%s %s），请在 %s 前审批。",
+			req.RequesterName, req.Action, req.Method, req.Path, approval.ExpiresAt.Format("2006-01-02 15:04:05")))
+	return approval, nil
+}
+
+// Approve 审批通过，执行期限从审批时间开始计算
+func (s *ApprovalService) Approve(id, reviewerID uint, reviewerName, comment string) (*Models.AdminApproval, error) {
+	return s.review(id, reviewerID, reviewerName, comment, Models.ApprovalStatusApproved)
+}
+
+// Reject 拒绝待审批的操作
+func (s *ApprovalService) Reject(id, reviewerID uint, reviewerName, comment string) (*Models.AdminApproval, error) {
+	return s.review(id, reviewerID, reviewerName, comment, Models.ApprovalStatusRejected)
+}
+
+// review 以条件更新将待审批记录改为通过或拒绝，避免同一记录被重复审批
+func (s *ApprovalService) review(id, reviewerID uint, reviewerName, comment, status string) (*Models.AdminApproval, error) {
+	approval, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if approval.Status != Models.ApprovalStatusPending {
+		return approval, ErrApprovalNotPending
+	}
+	if approval.RequestedBy == reviewerID {
+		return approval, ErrApprovalSelfReview
+	}
+
+	now := s.now()
+	updates := map[string]interface{}{
+		"status":         status,
+		"reviewed_by":    reviewerID,
+		"reviewer_name":  reviewerName,
+		"review_comment": comment,
+		"reviewed_at":    now,
+	}
+	if status == Models.ApprovalStatusApproved {
+		updates["expires_at"] = now.Add(s.config.ExecutionTTL)
+	}
+	result := s.db.Model(&Models.AdminApproval{}).
+		Where("id = ? AND status = ? AND expires_at > ?", id, Models.ApprovalStatusPending, now).
+		Updates(updates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return s.reload(id, ErrApprovalNotPending)
+	}
+	approval, err = s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	auditAction, verb := Models.AuditActionApprovalApprove, "通过"
+	if status == Models.ApprovalStatusRejected {
+		auditAction, verb = Models.AuditActionApprovalReject, "拒绝"
+	}
+	s.audit(Models.NewAuditLog(reviewerID, reviewerName, auditAction, "approval", approval.ID).
+		SetLevel(Models.AuditLevelWarning).
+		SetDescription(fmt.Sprintf("管理员 %s %s了 %s 发起的操作 %s，意见：%s",
+			reviewerName, verb, approval.RequesterName, approval.Action, comment)).
+		SetMetadata(approvalMetadata(approval)))
+	if _, err := s.notifier().Notify(approval.RequestedBy, Models.NotificationTypeApproval, "敏感操作审批已"+verb,
+		fmt.Sprintf("管理员 %s %s了你发起的操作 %s（%s %s）。", reviewerName, verb, approval.Action, approval.Method, approval.Path),
+		map[string]interface{}{"approval_id": approval.ID, "status": approval.Status}); err != nil {
+		log.Printf("发送审批结果通知失败: %v", err)
+	}
+	return approval, nil
+}
+
+// Claim 占用已通过的审批记录以执行请求
+// 请求的操作、方法、路径和请求体必须与审批记录一致，且只能由发起人在执行期限内执行一次。
+func (s *ApprovalService) Claim(id uint, req ApprovalRequest) (*Models.AdminApproval, error) {
+	approval, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if approval.Status != Models.ApprovalStatusApproved {
+		return approval, ErrApprovalNotApproved
+	}
+	if approval.Action != req.Action || approval.Method != req.Method || approval.Path != req.Path ||
+		approval.RequestedBy != req.RequestedBy || approval.PayloadHash != approvalPayloadHash(req.Payload) {
+		return approval, ErrApprovalMismatch
+	}
+
+	now := s.now()
+	result := s.db.Model(&Models.AdminApproval{}).
+		Where("id = ? AND status = ? AND expires_at > ?", id, Models.ApprovalStatusApproved, now).
+		Updates(map[string]interface{}{"status": Models.ApprovalStatusExecuted, "executed_at": now})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return s.reload(id, ErrApprovalNotApproved)
+	}
+	approval.Status = Models.ApprovalStatusExecuted
+	approval.ExecutedAt = &now
+	return approval, nil
+}
+
+// Complete 记录执行结果并写入审计日志
+func (s *ApprovalService) Complete(approval *Models.AdminApproval, status int, req ApprovalRequest) {
+	approval.ExecutionStatus = status
+	s.db.Model(&Models.AdminApproval{}).Where("id = ?", approval.ID).Update("execution_status", status)
+
+	entry := Models.NewAuditLog(req.RequestedBy, req.RequesterName, Models.AuditActionApprovalExecute, "approval", approval.ID).
+		SetLevel(Models.AuditLevelWarning).
+		SetIPAddress(req.IPAddress).
+		SetRequestID(req.RequestID).
+		SetDescription(fmt.Sprintf("管理员 %s 执行了经 %s 审批的操作 %s：%s %s，响应状态 %d",
+			req.RequesterName, approval.ReviewerName, approval.Action, approval.Method, approval.Path, status)).
+		SetMetadata(approvalMetadata(approval))
+	if status >= http.StatusBadRequest {
+		entry.Status = Models.AuditStatusFailed
+	}
+	s.audit(entry)
+}
+
+// Get 获取审批记录，超过期限的待审批和已通过记录标记为过期
+func (s *ApprovalService) Get(id uint) (*Models.AdminApproval, error) {
+	var approval Models.AdminApproval
+	if err := s.db.First(&approval, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrApprovalNotFound
+		}
+		return nil, err
+	}
+	if approval.IsExpired(s.now()) {
+		if err := s.db.Model(&Models.AdminApproval{}).Where("id = ? AND status = ?", approval.ID, approval.Status).
+			Update("status", Models.ApprovalStatusExpired).Error; err != nil {
+			return nil, err
+		}
+		approval.Status = Models.ApprovalStatusExpired
+		return &approval, ErrApprovalExpired
+	}
+	return &approval, nil
+}
+
+// List 按列表查询分页获取审批记录，查询前将超过期限的记录标记为过期
+func (s *ApprovalService) List(q *Utils.ListQuery, req Utils.PageRequest) ([]Models.AdminApproval, Utils.PageMeta, error) {
+	if _, err := s.ExpireOverdue(); err != nil {
+		return nil, Utils.PageMeta{}, err
+	}
+	query, order, err := q.Apply(s.db.Model(&Models.AdminApproval{}), req)
+	if err != nil {
+		return nil, Utils.PageMeta{}, err
+	}
+	var approvals []Models.AdminApproval
+	meta, err := Utils.Paginate(query, req, order, &approvals)
+	return approvals, meta, err
+}
+
+// ExpireOverdue 将超过审批或执行期限的记录标记为过期
+func (s *ApprovalService) ExpireOverdue() (int64, error) {
+	result := s.db.Model(&Models.AdminApproval{}).
+		Where("status IN ? AND expires_at <= ?", []string{Models.ApprovalStatusPending, Models.ApprovalStatusApproved}, s.now()).
+		Update("status", Models.ApprovalStatusExpired)
+	return result.RowsAffected, result.Error
+}
+
+// reload 条件更新未命中时重新读取记录，期间过期的返回 ErrApprovalExpired，否则返回 fallback
+func (s *ApprovalService) reload(id uint, fallback error) (*Models.AdminApproval, error) {
+	approval, err := s.Get(id)
+	if err != nil {
+		return approval, err
+	}
+	return approval, fallback
+}
+
+// audit 保存审计日志，保存失败不影响审批
+func (s *ApprovalService) audit(entry *Models.AuditLog) {
+	if err := s.db.Create(entry).Error; err != nil {
+		log.Printf("保存审批审计日志失败: %v", err)
+	}
+}
+
+// notify 通知管理员有新的待审批操作
+func (s *ApprovalService) notify(approval *Models.AdminApproval, title, content string) {
+	if _, err := s.notifier().NotifyAdmins(Models.NotificationTypeApproval, title, content, map[string]interface{}{
+		"approval_id": approval.ID,
+		"action":      approval.Action,
+		"requester":   approval.RequesterName,
+		"expires_at":  approval.ExpiresAt,
+	}); err != nil {
+		log.Printf("发送审批通知失败: %v", err)
+	}
+}
+
+// notifier 站内通知服务，未初始化全局通知服务时使用当前数据库创建
+func (s *ApprovalService) notifier() *NotificationService {
+	if notifier := DefaultNotificationService(); notifier != nil {
+		return notifier
+	}
+	return NewNotificationService(s.db, nil)
+}
+
+// approvalMetadata 审计日志中的审批信息
+func approvalMetadata(approval *Models.AdminApproval) map[string]interface{} {
+	return map[string]interface{}{
+		"approval_id": approval.ID,
+		"action":      approval.Action,
+		"method":      approval.Method,
+		"path":        approval.Path,
+		"status":      approval.Status,
+		"requester":   approval.RequesterName,
+		"reviewer":    approval.ReviewerName,
+	}
+}
+
+// approvalPayloadHash 请求体的SHA-256摘要
+func approvalPayloadHash(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+var (
+	defaultApprovalService   *ApprovalService
+	defaultApprovalServiceMu sync.RWMutex
+)
+
+// SetDefaultApprovalService 设置全局双人审批服务
+func SetDefaultApprovalService(service *ApprovalService) {
+	defaultApprovalServiceMu.Lock()
+	defer defaultApprovalServiceMu.Unlock()
+	defaultApprovalService = service
+}
+
+// DefaultApprovalService 获取全局双人审批服务，未设置时返回 nil，敏感操作直接执行
+func DefaultApprovalService() *ApprovalService {
+	defaultApprovalServiceMu.RLock()
+	defer defaultApprovalServiceMu.RUnlock()
+	return defaultApprovalService
+}
@@ -0,0 +1,182 @@
+package Services
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrArchiveObjectNotFound 归档文件不存在
+var ErrArchiveObjectNotFound = errors.New("归档文件不存在")
+
+// ArchiveObjectStore 归档文件存储
+type ArchiveObjectStore interface {
+	// Put 写入归档文件，同名文件覆盖
+	Put(ctx context.Context, key string, data []byte) error
+	// Get 读取归档文件，不存在时返回 ErrArchiveObjectNotFound
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// NewArchiveObjectStore 根据归档配置创建归档存储
+func NewArchiveObjectStore(config *Config.ArchiveConfig) (ArchiveObjectStore, error) {
+	switch config.StoreName() {
+	case Config.ArchiveStoreLocal:
+		return NewLocalArchiveStore(config.LocalPath), nil
+	case Config.ArchiveStoreS3:
+		return NewS3ArchiveStore(config.S3.Endpoint, config.S3.Region, config.S3.Bucket,
+			config.S3.AccessKeyID, config.S3.SecretAccessKey, config.S3.Prefix, config.S3.PathStyle, nil), nil
+	default:
+		return nil, fmt.Errorf("不支持的归档存储: %s", config.Store)
+	}
+}
+
+// LocalArchiveStore 本地目录归档存储，对象键作为相对路径
+type LocalArchiveStore struct {
+	root string
+}
+
+// NewLocalArchiveStore 创建本地目录归档存储
+func NewLocalArchiveStore(root string) *LocalArchiveStore {
+	return &LocalArchiveStore{root: root}
+}
+
+// path 返回对象键对应的文件路径，拒绝跳出根目录的键
+func (s *LocalArchiveStore) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" {
+		return "", fmt.Errorf("无效的归档文件键: %q", key)
+	}
+	return filepath.Join(s.root, clean), nil
+}
+
+// Put 先写入临时文件再重命名，避免读取到写了一半的文件
+func (s *LocalArchiveStore) Put(ctx context.Context, key string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Get 读取归档文件
+func (s *LocalArchiveStore) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrArchiveObjectNotFound, key)
+	}
+	return data, err
+}
+
+// S3ArchiveStore S3兼容对象存储，使用 AWS Signature Version 4 签名
+type S3ArchiveStore struct {
+	endpoint        *url.URL
+	region          string
+	bucket          string
+	accessKeyID     string
+	secretAccessKey string
+	prefix          string
+	pathStyle       bool
+	client          *OutboundHTTPClient
+}
+
+// s3ArchiveDependency S3对象存储在出站HTTP客户端中的依赖名称
+const s3ArchiveDependency = "archive_s3"
+
+// NewS3ArchiveStore 创建S3兼容对象存储
+//
+// 请求经全局出站HTTP客户端发送，超时60秒；client 不为空时使用该客户端建立连接，统计和熔断状态仍与全局客户端共享。
+func NewS3ArchiveStore(endpoint, region, bucket, accessKeyID, secretAccessKey, prefix string, pathStyle bool, client *http.Client) *S3ArchiveStore {
+	outbound := outboundClientFor(s3ArchiveDependency, 60*time.Second)
+	if client != nil {
+		outbound = outbound.WithHTTPClient(client)
+	}
+	parsed, err := url.Parse(strings.TrimRight(endpoint, "/"))
+	if err != nil || parsed.Host == "" {
+		parsed = &url.URL{Scheme: "https", Host: strings.TrimRight(endpoint, "/")}
+	}
+	return &S3ArchiveStore{
+		endpoint:        parsed,
+		region:          region,
+		bucket:          bucket,
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		prefix:          prefix,
+		pathStyle:       pathStyle,
+		client:          outbound,
+	}
+}
+
+// objectURL 返回对象地址，路径形式为 endpoint/bucket/key，否则为 bucket.endpoint/key
+func (s *S3ArchiveStore) objectURL(key string) string {
+	u := *s.endpoint
+	objectKey := strings.TrimLeft(s.prefix+key, "/")
+	if s.pathStyle {
+		u.Path = "/" + s.bucket + "/" + objectKey
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = "/" + objectKey
+	}
+	return u.String()
+}
+
+// do 发送签名请求
+func (s *S3ArchiveStore) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	signAWSRequest(req, body, s.accessKeyID, s.secretAccessKey, s.region, "s3", time.Now())
+	return s.client.Do(s3ArchiveDependency, req)
+}
+
+// Put 上传归档文件
+func (s *S3ArchiveStore) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("上传归档文件失败: status=%d, body=%s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// Get 下载归档文件
+func (s *S3ArchiveStore) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrArchiveObjectNotFound, key)
+	}
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("下载归档文件失败: status=%d, body=%s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return io.ReadAll(resp.Body)
+}
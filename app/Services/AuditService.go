@@ -4,7 +4,9 @@ import (
 	"cloud-platform-api/app/Database"
 	"cloud-platform-api/app/Models"
 	"cloud-platform-api/app/Storage"
+	"cloud-platform-api/app/Utils"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -175,3 +177,20 @@ func (s *AuditService) GetAuditLogs(page, limit int, filters map[string]interfac
 
 	return logs, total, err
 }
+
+// ListAuditLogs 按列表查询分页获取审计日志
+// 筛选、排序和返回字段由 q 按白名单生成，支持页码分页和游标分页
+func (s *AuditService) ListAuditLogs(q *Utils.ListQuery, req Utils.PageRequest) ([]Models.AuditLog, Utils.PageMeta, error) {
+	db, ok := s.DB.(*gorm.DB)
+	if !ok || db == nil {
+		return nil, Utils.PageMeta{}, errors.New("审计服务未配置数据库")
+	}
+
+	query, order, err := q.Apply(db.Model(&Models.AuditLog{}), req)
+	if err != nil {
+		return nil, Utils.PageMeta{}, err
+	}
+	var logs []Models.AuditLog
+	meta, err := Utils.Paginate(query, req, order, &logs)
+	return logs, meta, err
+}
@@ -4,10 +4,12 @@ import (
 	"cloud-platform-api/app/Config"
 	"cloud-platform-api/app/Database"
 	"cloud-platform-api/app/Http/Requests"
+	"cloud-platform-api/app/I18n"
 	"cloud-platform-api/app/Models"
 	"cloud-platform-api/app/Utils"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -146,7 +148,7 @@ func (s *AuthService) Register(request Requests.RegisterRequest) (*Models.User,
 	// 如果服务有自定义数据库连接，使用它；否则使用全局连接
 	db := s.getDB()
 	if db == nil {
-		return nil, errors.New("database connection not available")
+		return nil, I18n.NewError("auth.database_unavailable", "database connection not available")
 	}
 
 	// 检查用户名是否已存在
@@ -154,14 +156,14 @@ func (s *AuthService) Register(request Requests.RegisterRequest) (*Models.User,
 	// 如果查询成功（err == nil），说明用户名已存在
 	var existingUser Models.User
 	if err := db.Where("username = ?", request.Username).First(&existingUser).Error; err == nil {
-		return nil, errors.New("username already exists")
+		return nil, I18n.NewError("auth.username_exists", "username already exists")
 	}
 
 	// 检查邮箱是否已存在
 	// 使用数据库查询检查唯一性
 	// 如果查询成功（err == nil），说明邮箱已存在
 	if err := db.Where("email = ?", request.Email).First(&existingUser).Error; err == nil {
-		return nil, errors.New("email already exists")
+		return nil, I18n.NewError("auth.email_exists", "email already exists")
 	}
 
 	// 验证密码强度
@@ -170,7 +172,7 @@ func (s *AuthService) Register(request Requests.RegisterRequest) (*Models.User,
 	isValid, validationErrors := Utils.ValidatePasswordStrength(request.Password)
 	if !isValid {
 		// 密码强度不足，返回详细错误信息
-		return nil, fmt.Errorf("password validation failed: %s", strings.Join(validationErrors, "; "))
+		return nil, I18n.NewError("auth.password_invalid", "password validation failed: :reasons", I18n.Params{"reasons": strings.Join(validationErrors, "; ")})
 	}
 
 	// 哈希密码
@@ -183,17 +185,33 @@ func (s *AuthService) Register(request Requests.RegisterRequest) (*Models.User,
 
 	// 创建用户对象
 	// 设置用户名、邮箱、哈希密码、默认角色和状态
+	now := time.Now()
 	user := &Models.User{
-		Username: request.Username, // 用户名
-		Email:    request.Email,    // 邮箱
-		Password: hashedPassword,   // 哈希后的密码（不是明文）
-		Role:     "user",           // 默认角色：普通用户
-		Status:   1,                 // 默认状态：启用
+		Username:          request.Username, // 用户名
+		Email:             request.Email,    // 邮箱
+		Password:          hashedPassword,   // 哈希后的密码（不是明文）
+		Role:              "user",           // 默认角色：普通用户
+		Status:            1,                // 默认状态：启用
+		PasswordChangedAt: &now,             // 密码过期从注册时开始计算
 	}
 
-	// 保存用户到数据库
+	// 保存用户到数据库，同一事务中写入注册和用户创建事件
 	// GORM会自动设置CreatedAt和UpdatedAt字段
-	if err := s.getDB().Create(user).Error; err != nil {
+	err = s.getDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		if err := PublishDomainEvent(tx, EventUserRegistered, "user", fmt.Sprint(user.ID), map[string]interface{}{
+			"user_id":  user.ID,
+			"username": user.Username,
+			"email":    user.Email,
+			"role":     user.Role,
+		}); err != nil {
+			return err
+		}
+		return publishUserCreated(tx, user, "register")
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -262,7 +280,7 @@ func (s *AuthService) Login(request Requests.LoginRequest) (string, *Models.User
 		// 注意：返回"invalid credentials"而不是"user not found"
 		// 这样可以防止攻击者通过错误信息判断用户是否存在（用户枚举攻击）
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return "", nil, errors.New("invalid credentials")
+			return "", nil, I18n.NewError("auth.invalid_credentials", "invalid credentials")
 		}
 		// 其他数据库错误
 		return "", nil, err
@@ -273,14 +291,20 @@ func (s *AuthService) Login(request Requests.LoginRequest) (string, *Models.User
 	// 注意：密码错误也返回"invalid credentials"，与用户不存在相同
 	// 这样可以防止攻击者通过错误信息判断密码是否正确
 	if !Utils.CheckPassword(request.Password, user.Password) {
-		return "", nil, errors.New("invalid credentials")
+		return "", nil, I18n.NewError("auth.invalid_credentials", "invalid credentials")
 	}
 
 	// 检查用户状态
 	// Status == 1表示账户启用，其他值表示禁用
 	// 禁用的账户不能登录
 	if user.Status != 1 {
-		return "", nil, errors.New("account is disabled")
+		return "", nil, I18n.NewError("auth.account_disabled", "account is disabled")
+	}
+
+	// 密码已过期时标记为必须修改密码
+	// 客户端根据返回的must_change_password引导用户修改密码，其他接口由中间件限制访问
+	if NewPasswordExpiryService(s.getDB(), nil, nil).Status(&user).MustChange {
+		user.MustChangePassword = true
 	}
 
 	// 更新最后登录时间
@@ -352,9 +376,14 @@ func (s *AuthService) Logout(userID string) error {
 // 1. 根据用户ID获取用户信息
 // 2. 排除敏感信息（如密码）
 // 3. 返回完整的用户资料
+// 4. 启用模型缓存时先读缓存
 func (s *AuthService) GetProfile(userID string) (*Models.User, error) {
-	var user Models.User
-	if err := s.getDB().First(&user, userID).Error; err != nil {
+	id, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	user, err := FindUserCached(s.getDB(), uint(id))
+	if err != nil {
 		return nil, err
 	}
 
@@ -369,7 +398,7 @@ func (s *AuthService) GetProfile(userID string) (*Models.User, error) {
 // 1. 验证用户权限
 // 2. 更新允许修改的字段
 // 3. 验证数据有效性
-// 4. 保存更新后的用户信息
+// 4. 使用乐观锁保存，request.Version 与当前版本不一致时返回 *Models.VersionConflictError
 func (s *AuthService) UpdateProfile(userID string, request Requests.UpdateProfileRequest) (*Models.User, error) {
 	var user Models.User
 	if err := s.getDB().First(&user, userID).Error; err != nil {
@@ -380,7 +409,7 @@ func (s *AuthService) UpdateProfile(userID string, request Requests.UpdateProfil
 	if request.Username != "" && request.Username != user.Username {
 		var existingUser Models.User
 		if err := s.getDB().Where("username = ? AND id != ?", request.Username, userID).First(&existingUser).Error; err == nil {
-			return nil, errors.New("username already exists")
+			return nil, I18n.NewError("auth.username_exists", "username already exists")
 		}
 		user.Username = request.Username
 	}
@@ -389,7 +418,7 @@ func (s *AuthService) UpdateProfile(userID string, request Requests.UpdateProfil
 	if request.Email != "" && request.Email != user.Email {
 		var existingUser Models.User
 		if err := s.getDB().Where("email = ? AND id != ?", request.Email, userID).First(&existingUser).Error; err == nil {
-			return nil, errors.New("email already exists")
+			return nil, I18n.NewError("auth.email_exists", "email already exists")
 		}
 		user.Email = request.Email
 	}
@@ -398,8 +427,17 @@ func (s *AuthService) UpdateProfile(userID string, request Requests.UpdateProfil
 		user.Avatar = request.Avatar
 	}
 
-	// 保存更新
-	if err := s.getDB().Save(&user).Error; err != nil {
+	// 偏好语言只接受已加载的语言包
+	if request.Locale != "" {
+		locale := I18n.MatchLocale([]string{request.Locale}, I18n.GetTranslator().Locales())
+		if locale == "" {
+			return nil, I18n.NewError("auth.locale_unsupported", "unsupported locale: :locale", I18n.Params{"locale": request.Locale})
+		}
+		user.Locale = locale
+	}
+
+	// 保存更新，期间被其他请求修改时返回版本冲突
+	if err := Models.SaveWithVersion(s.getDB(), &user, request.Version); err != nil {
 		return nil, err
 	}
 
@@ -428,15 +466,20 @@ func (s *AuthService) RefreshToken(token string) (string, error) {
 		return "", err
 	}
 
+	// 模拟令牌有效期由模拟会话决定，不能刷新
+	if claims.IsImpersonation() {
+		return "", I18n.NewError("auth.impersonation_not_refreshable", "impersonation tokens cannot be refreshed")
+	}
+
 	// 验证用户是否仍然存在且有效
 	var user Models.User
 	if err := s.getDB().First(&user, claims.UserID).Error; err != nil {
-		return "", errors.New("user not found or account disabled")
+		return "", I18n.NewError("auth.user_unavailable", "user not found or account disabled")
 	}
 
 	// 检查用户状态
 	if user.Status != 1 {
-		return "", errors.New("account is disabled")
+		return "", I18n.NewError("auth.account_disabled", "account is disabled")
 	}
 
 	// 生成新token
@@ -459,14 +502,14 @@ func (s *AuthService) RequestPasswordReset(email string) error {
 	var user Models.User
 	if err := s.getDB().Where("email = ?", email).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("email not found")
+			return I18n.NewError("auth.email_not_found", "email not found")
 		}
 		return err
 	}
 
 	// 检查用户状态
 	if user.Status != 1 {
-		return errors.New("account is disabled")
+		return I18n.NewError("auth.account_disabled", "account is disabled")
 	}
 
 	// 生成密码重置token
@@ -517,12 +560,12 @@ func (s *AuthService) ResetPassword(resetToken, newPassword string) error {
 	// 查找用户
 	var user Models.User
 	if err := s.getDB().First(&user, claims.UserID).Error; err != nil {
-		return errors.New("user not found")
+		return I18n.NewError("auth.user_not_found", "user not found")
 	}
 
 	// 检查用户状态
 	if user.Status != 1 {
-		return errors.New("account is disabled")
+		return I18n.NewError("auth.account_disabled", "account is disabled")
 	}
 
 	// 哈希新密码
@@ -532,7 +575,7 @@ func (s *AuthService) ResetPassword(resetToken, newPassword string) error {
 	}
 
 	// 更新密码
-	if err := s.getDB().Model(&user).Update("password", hashedPassword).Error; err != nil {
+	if err := s.getDB().Model(&user).Updates(passwordChangedUpdates(hashedPassword)).Error; err != nil {
 		return err
 	}
 
@@ -553,6 +596,26 @@ func (s *AuthService) ResetPassword(resetToken, newPassword string) error {
 	return nil
 }
 
+// ChangePassword 修改当前用户密码
+// 功能说明：
+// 1. 验证当前密码和新密码强度后更新密码
+// 2. 重置必须修改密码标记和密码过期提醒记录，密码过期时间重新计算
+// 3. 记录密码修改操作到审计日志
+func (s *AuthService) ChangePassword(userID string, request Requests.PasswordChangeRequest) error {
+	var user Models.User
+	if err := s.getDB().First(&user, userID).Error; err != nil {
+		return I18n.NewError("auth.user_not_found", "user not found")
+	}
+
+	if err := NewUserServiceWithDB(s.getDB()).ChangePassword(user.ID, request.CurrentPassword, request.NewPassword); err != nil {
+		return err
+	}
+
+	auditService := NewAuditService(s.getDB())
+	auditService.LogUserAction(nil, user.ID, user.Username, "password_change", "user", user.ID, "密码修改成功")
+	return nil
+}
+
 // SendEmailVerification 发送邮箱验证
 // 功能说明：
 // 1. 生成邮箱验证token
@@ -566,7 +629,7 @@ func (s *AuthService) SendEmailVerification(userID string) error {
 
 	// 检查邮箱是否已验证
 	if user.IsEmailVerified() {
-		return errors.New("email already verified")
+		return I18n.NewError("auth.email_already_verified", "email already verified")
 	}
 
 	// 生成邮箱验证token
@@ -617,7 +680,7 @@ func (s *AuthService) VerifyEmail(verificationToken string) error {
 	// 查找用户
 	var user Models.User
 	if err := s.getDB().First(&user, claims.UserID).Error; err != nil {
-		return errors.New("user not found")
+		return I18n.NewError("auth.user_not_found", "user not found")
 	}
 
 	// 更新邮箱验证状态
@@ -0,0 +1,665 @@
+package Services
+
+import (
+	"bufio"
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// backupEncryptedExt 加密备份的扩展名，加在原备份文件名之后，如 db_20240101_020000.sql.gz.enc
+const backupEncryptedExt = ".enc"
+
+// backupEncryptionMagic 加密备份的文件头标识
+const backupEncryptionMagic = "CPBKENC1"
+
+// backupEncryptionChunkSize 每个加密分块的明文大小，分块加密使大文件无需整体读入内存
+const backupEncryptionChunkSize = 1 << 20
+
+// backupEscrowLabel 托管密钥RSA-OAEP加密的标签
+var backupEscrowLabel = []byte("backup-escrow")
+
+var (
+	// ErrBackupNotEncrypted 备份文件未加密
+	ErrBackupNotEncrypted = errors.New("备份文件未加密")
+	// ErrBackupDecryptFailed 解密失败，备份被截断、篡改或密钥不正确
+	ErrBackupDecryptFailed = errors.New("备份解密失败：文件被截断或篡改，或密钥不正确")
+	// ErrBackupKeyUnavailable 未配置可用的主密钥
+	ErrBackupKeyUnavailable = errors.New("未配置备份主密钥")
+	// ErrBackupKeyVersionUnknown 主密钥版本不存在，轮换后需在 STORAGE_ENCRYPTION_PREVIOUS_KEYS 中保留旧密钥
+	ErrBackupKeyVersionUnknown = errors.New("备份主密钥版本不存在")
+	// ErrBackupEscrowMissing 备份没有托管密钥
+	ErrBackupEscrowMissing = errors.New("备份没有托管密钥")
+)
+
+// BackupKeyProvider 备份主密钥
+// 每个备份使用随机生成的数据密钥加密，主密钥只用于包装数据密钥；实现KMS时只需实现包装和解包
+type BackupKeyProvider interface {
+	// Name 密钥来源名称，写入备份元数据
+	Name() string
+	// WrapKey 用当前版本的主密钥包装数据密钥，返回包装结果和主密钥版本
+	WrapKey(ctx context.Context, dataKey []byte) ([]byte, string, error)
+	// UnwrapKey 用指定版本的主密钥解包数据密钥
+	UnwrapKey(ctx context.Context, wrapped []byte, version string) ([]byte, error)
+}
+
+// BackupEncryptionHeader 加密备份的元数据，以JSON写在文件头，并作为每个分块的附加认证数据
+type BackupEncryptionHeader struct {
+	Algorithm   string    `json:"algorithm"`
+	KeyProvider string    `json:"key_provider"`
+	KeyVersion  string    `json:"key_version"`
+	WrappedKey  []byte    `json:"wrapped_key"`
+	EscrowKeyID string    `json:"escrow_key_id,omitempty"` // 托管公钥指纹
+	EscrowKey   []byte    `json:"escrow_key,omitempty"`    // 托管公钥加密的数据密钥
+	ChunkSize   int       `json:"chunk_size"`
+	NoncePrefix []byte    `json:"nonce_prefix"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Summary 不含密钥材料的元数据，用于备份列表和日志
+func (h *BackupEncryptionHeader) Summary() map[string]interface{} {
+	summary := map[string]interface{}{
+		"algorithm":    h.Algorithm,
+		"key_provider": h.KeyProvider,
+		"key_version":  h.KeyVersion,
+	}
+	if h.EscrowKeyID != "" {
+		summary["escrow_key_id"] = h.EscrowKeyID
+	}
+	return summary
+}
+
+// NewBackupKeyProvider 按备份配置创建主密钥
+func NewBackupKeyProvider(config *BackupConfig) (BackupKeyProvider, error) {
+	switch config.EncryptionKeyProvider {
+	case "", "local":
+		key, err := Config.DecodeBackupKey(config.EncryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("备份加密密钥无效: %v", err)
+		}
+		previous := make(map[string][]byte, len(config.EncryptionPreviousKeys))
+		for _, entry := range config.EncryptionPreviousKeys {
+			version, encoded, ok := strings.Cut(entry, ":")
+			if !ok || version == "" {
+				return nil, fmt.Errorf("轮换前的备份加密密钥格式应为 版本:密钥")
+			}
+			previousKey, err := Config.DecodeBackupKey(encoded)
+			if err != nil {
+				return nil, fmt.Errorf("备份加密密钥 %s 无效: %v", version, err)
+			}
+			previous[version] = previousKey
+		}
+		version := config.EncryptionKeyVersion
+		if version == "" {
+			version = "v1"
+		}
+		return NewLocalBackupKeyProvider(version, key, previous), nil
+	case "vault":
+		if config.VaultAddress == "" || config.VaultToken == "" || config.VaultTransitKey == "" {
+			return nil, fmt.Errorf("使用Vault加密备份时需要配置地址、令牌和密钥名")
+		}
+		return NewVaultTransitKeyProvider(config.VaultAddress, config.VaultToken, config.VaultTransitKey), nil
+	default:
+		return nil, fmt.Errorf("不支持的备份密钥来源: %s", config.EncryptionKeyProvider)
+	}
+}
+
+// LocalBackupKeyProvider 本地主密钥
+// 当前版本的密钥用于包装新备份的数据密钥，轮换前的密钥只用于解密旧备份
+type LocalBackupKeyProvider struct {
+	version string
+	keys    map[string][]byte
+}
+
+// NewLocalBackupKeyProvider 创建本地主密钥，previous 为轮换前的版本到密钥的映射
+func NewLocalBackupKeyProvider(version string, key []byte, previous map[string][]byte) *LocalBackupKeyProvider {
+	keys := make(map[string][]byte, len(previous)+1)
+	for previousVersion, previousKey := range previous {
+		keys[previousVersion] = previousKey
+	}
+	keys[version] = key
+	return &LocalBackupKeyProvider{version: version, keys: keys}
+}
+
+// Name 密钥来源名称
+func (p *LocalBackupKeyProvider) Name() string {
+	return "local"
+}
+
+// WrapKey 用AES-256-GCM包装数据密钥，结果为 nonce + 密文
+func (p *LocalBackupKeyProvider) WrapKey(ctx context.Context, dataKey []byte) ([]byte, string, error) {
+	aead, err := newBackupAEAD(p.keys[p.version])
+	if err != nil {
+		return nil, "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, "", err
+	}
+	return aead.Seal(nonce, nonce, dataKey, []byte("backup-data-key:"+p.version)), p.version, nil
+}
+
+// UnwrapKey 解包数据密钥
+func (p *LocalBackupKeyProvider) UnwrapKey(ctx context.Context, wrapped []byte, version string) ([]byte, error) {
+	key, ok := p.keys[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrBackupKeyVersionUnknown, version)
+	}
+	aead, err := newBackupAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, ErrBackupDecryptFailed
+	}
+	dataKey, err := aead.Open(nil, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], []byte("backup-data-key:"+version))
+	if err != nil {
+		return nil, ErrBackupDecryptFailed
+	}
+	return dataKey, nil
+}
+
+// VaultTransitKeyProvider 使用 HashiCorp Vault Transit 引擎作为主密钥
+// 主密钥不离开Vault，在Vault中轮换密钥后新备份自动使用新版本，旧备份仍可解密
+type VaultTransitKeyProvider struct {
+	address string
+	token   string
+	key     string
+	client  *http.Client
+}
+
+// NewVaultTransitKeyProvider 创建Vault Transit主密钥
+func NewVaultTransitKeyProvider(address, token, key string) *VaultTransitKeyProvider {
+	return &VaultTransitKeyProvider{
+		address: strings.TrimRight(address, "/"),
+		token:   token,
+		key:     key,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name 密钥来源名称
+func (p *VaultTransitKeyProvider) Name() string {
+	return "vault"
+}
+
+// WrapKey 调用 transit/encrypt 包装数据密钥，包装结果为Vault密文（vault:v<版本>:...）
+func (p *VaultTransitKeyProvider) WrapKey(ctx context.Context, dataKey []byte) ([]byte, string, error) {
+	var response struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	body := map[string]string{"plaintext": base64.StdEncoding.EncodeToString(dataKey)}
+	if err := p.call(ctx, "encrypt", body, &response); err != nil {
+		return nil, "", err
+	}
+	parts := strings.SplitN(response.Data.Ciphertext, ":", 3)
+	if len(parts) != 3 || parts[0] != "vault" {
+		return nil, "", fmt.Errorf("Vault返回的密文格式错误")
+	}
+	return []byte(response.Data.Ciphertext), parts[1], nil
+}
+
+// UnwrapKey 调用 transit/decrypt 解包数据密钥，版本已包含在Vault密文中
+func (p *VaultTransitKeyProvider) UnwrapKey(ctx context.Context, wrapped []byte, version string) ([]byte, error) {
+	var response struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	if err := p.call(ctx, "decrypt", map[string]string{"ciphertext": string(wrapped)}, &response); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(response.Data.Plaintext)
+}
+
+// call 调用Transit接口
+func (p *VaultTransitKeyProvider) call(ctx context.Context, operation string, body interface{}, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("%s/v1/transit/%s/%s", p.address, operation, url.PathEscape(p.key))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Token", p.token)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求Vault失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("Vault %s 失败: HTTP %d %s", operation, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// SetKeyProvider 设置备份主密钥，用于接入其他KMS；设置后忽略配置中的密钥来源
+func (s *BackupService) SetKeyProvider(provider BackupKeyProvider) {
+	s.keyProvider = provider
+	s.keyProviderErr = nil
+}
+
+// IsEncryptedBackup 备份文件是否已加密
+func IsEncryptedBackup(backupPath string) bool {
+	return strings.HasSuffix(backupPath, backupEncryptedExt)
+}
+
+// DecryptBackup 用主密钥解密备份，写入 outputPath
+func (s *BackupService) DecryptBackup(ctx context.Context, backupPath, outputPath string) error {
+	return writeDecryptedBackup(backupPath, outputPath, s.unwrapWithProvider(ctx))
+}
+
+// DecryptBackupWithEscrow 用托管私钥解密备份，主密钥不可用时恢复数据
+func DecryptBackupWithEscrow(backupPath, outputPath string, privateKey *rsa.PrivateKey) error {
+	return writeDecryptedBackup(backupPath, outputPath, func(header *BackupEncryptionHeader) ([]byte, error) {
+		if len(header.EscrowKey) == 0 {
+			return nil, ErrBackupEscrowMissing
+		}
+		if keyID, err := escrowKeyID(&privateKey.PublicKey); err == nil && keyID != header.EscrowKeyID {
+			return nil, fmt.Errorf("托管私钥与备份的托管公钥不匹配（备份使用 %s）", header.EscrowKeyID)
+		}
+		dataKey, err := rsa.DecryptOAEP(sha256.New(), nil, privateKey, header.EscrowKey, backupEscrowLabel)
+		if err != nil {
+			return nil, ErrBackupDecryptFailed
+		}
+		return dataKey, nil
+	})
+}
+
+// LoadEscrowPrivateKey 读取PEM格式的托管私钥（PKCS#1或PKCS#8）
+func LoadEscrowPrivateKey(path string) (*rsa.PrivateKey, error) {
+	block, err := readPEMFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析托管私钥失败: %v", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("托管私钥必须为RSA密钥")
+	}
+	return key, nil
+}
+
+// ReadBackupEncryptionHeader 读取加密备份的元数据
+func ReadBackupEncryptionHeader(backupPath string) (*BackupEncryptionHeader, error) {
+	file, err := os.Open(backupPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	header, _, err := readEncryptionHeader(bufio.NewReader(file))
+	return header, err
+}
+
+// encryptBackup 启用加密时加密备份文件，加密后删除明文备份
+// 功能说明：
+// 1. 为每个备份随机生成数据密钥，数据密钥由主密钥包装，配置了托管公钥时另以托管公钥加密
+// 2. 使用AES-256-GCM分块加密，分块序号和是否为最后一块参与认证，截断和调换分块都能被发现
+// 3. 元数据（算法、密钥来源、主密钥版本）写入文件头并记录在备份信息中
+func (s *BackupService) encryptBackup(backupInfo *BackupInfo) error {
+	if !s.config.EnableEncryption {
+		return nil
+	}
+	if s.keyProviderErr != nil {
+		return s.keyProviderErr
+	}
+	if s.keyProvider == nil {
+		return ErrBackupKeyUnavailable
+	}
+
+	dataKey := make([]byte, 32)
+	noncePrefix := make([]byte, 4)
+	if _, err := rand.Read(dataKey); err != nil {
+		return err
+	}
+	if _, err := rand.Read(noncePrefix); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	wrapped, version, err := s.keyProvider.WrapKey(ctx, dataKey)
+	if err != nil {
+		return fmt.Errorf("包装备份数据密钥失败: %v", err)
+	}
+	header := &BackupEncryptionHeader{
+		Algorithm:   "AES-256-GCM",
+		KeyProvider: s.keyProvider.Name(),
+		KeyVersion:  version,
+		WrappedKey:  wrapped,
+		ChunkSize:   backupEncryptionChunkSize,
+		NoncePrefix: noncePrefix,
+		CreatedAt:   time.Now(),
+	}
+	if s.config.EscrowPublicKey != "" {
+		publicKey, err := loadEscrowPublicKey(s.config.EscrowPublicKey)
+		if err != nil {
+			return err
+		}
+		if header.EscrowKey, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, dataKey, backupEscrowLabel); err != nil {
+			return fmt.Errorf("托管数据密钥失败: %v", err)
+		}
+		if header.EscrowKeyID, err = escrowKeyID(publicKey); err != nil {
+			return err
+		}
+	}
+
+	encryptedPath := backupInfo.Path + backupEncryptedExt
+	if err := encryptBackupFile(backupInfo.Path, encryptedPath, header, dataKey); err != nil {
+		os.Remove(encryptedPath)
+		return fmt.Errorf("加密备份失败: %v", err)
+	}
+	if err := os.Remove(backupInfo.Path); err != nil {
+		return fmt.Errorf("删除明文备份失败: %v", err)
+	}
+	fileInfo, err := os.Stat(encryptedPath)
+	if err != nil {
+		return err
+	}
+	backupInfo.Path = encryptedPath
+	backupInfo.Size = fileInfo.Size()
+	if backupInfo.Metadata == nil {
+		backupInfo.Metadata = make(map[string]interface{})
+	}
+	backupInfo.Metadata["encryption"] = header.Summary()
+	return nil
+}
+
+// decryptBackupInto 用主密钥把加密备份解密到 dir，文件名为去掉 .enc 后的原备份文件名
+func (s *BackupService) decryptBackupInto(ctx context.Context, backupPath, dir string) (string, error) {
+	outputPath := filepath.Join(dir, strings.TrimSuffix(filepath.Base(backupPath), backupEncryptedExt))
+	if err := s.DecryptBackup(ctx, backupPath, outputPath); err != nil {
+		return "", err
+	}
+	return outputPath, nil
+}
+
+// verifyEncryptedBackup 解密全部分块完成认证，内层为gzip时同时检查gzip完整性
+func (s *BackupService) verifyEncryptedBackup(backupPath string) error {
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(decryptBackupFile(backupPath, writer, s.unwrapWithProvider(context.Background())))
+	}()
+	defer reader.Close()
+
+	err := verifyArchiveStream(strings.TrimSuffix(backupPath, backupEncryptedExt), reader)
+	if err == nil {
+		_, err = io.Copy(io.Discard, reader)
+	}
+	return err
+}
+
+// unwrapWithProvider 用主密钥解包备份的数据密钥
+func (s *BackupService) unwrapWithProvider(ctx context.Context) func(*BackupEncryptionHeader) ([]byte, error) {
+	return func(header *BackupEncryptionHeader) ([]byte, error) {
+		if s.keyProvider == nil {
+			if s.keyProviderErr != nil {
+				return nil, s.keyProviderErr
+			}
+			return nil, ErrBackupKeyUnavailable
+		}
+		if header.KeyProvider != s.keyProvider.Name() {
+			return nil, fmt.Errorf("备份使用 %s 主密钥加密，当前配置为 %s", header.KeyProvider, s.keyProvider.Name())
+		}
+		return s.keyProvider.UnwrapKey(ctx, header.WrappedKey, header.KeyVersion)
+	}
+}
+
+// encryptBackupFile 加密文件
+// 文件格式：标识（8字节）+ 元数据长度（4字节，大端）+ 元数据JSON + 分块密文
+func encryptBackupFile(sourcePath, targetPath string, header *BackupEncryptionHeader, dataKey []byte) error {
+	source, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	target, err := os.OpenFile(targetPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer target.Close()
+
+	headerBytes, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(target)
+	writer.WriteString(backupEncryptionMagic)
+	binary.Write(writer, binary.BigEndian, uint32(len(headerBytes)))
+	writer.Write(headerBytes)
+
+	aead, err := newBackupAEAD(dataKey)
+	if err != nil {
+		return err
+	}
+	reader := bufio.NewReaderSize(source, header.ChunkSize)
+	buf := make([]byte, header.ChunkSize)
+	for counter := uint64(0); ; counter++ {
+		n, err := io.ReadFull(reader, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		final := err != nil
+		if !final {
+			if final, err = atEOF(reader); err != nil {
+				return err
+			}
+		}
+		nonce, aad := backupChunkParams(header, headerBytes, counter, final)
+		if _, err := writer.Write(aead.Seal(nil, nonce, buf[:n], aad)); err != nil {
+			return err
+		}
+		if final {
+			break
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return target.Close()
+}
+
+// writeDecryptedBackup 解密备份写入文件，失败时删除不完整的输出
+func writeDecryptedBackup(backupPath, outputPath string, unwrap func(*BackupEncryptionHeader) ([]byte, error)) error {
+	output, err := os.OpenFile(outputPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(output)
+	err = decryptBackupFile(backupPath, writer, unwrap)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if closeErr := output.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(outputPath)
+	}
+	return err
+}
+
+// decryptBackupFile 解密备份写入 w，任一分块认证失败时返回 ErrBackupDecryptFailed
+func decryptBackupFile(backupPath string, w io.Writer, unwrap func(*BackupEncryptionHeader) ([]byte, error)) error {
+	file, err := os.Open(backupPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReaderSize(file, backupEncryptionChunkSize)
+	header, headerBytes, err := readEncryptionHeader(reader)
+	if err != nil {
+		return err
+	}
+	if header.ChunkSize <= 0 || header.ChunkSize > 64*backupEncryptionChunkSize || len(header.NoncePrefix) != 4 {
+		return ErrBackupDecryptFailed
+	}
+	dataKey, err := unwrap(header)
+	if err != nil {
+		return err
+	}
+	aead, err := newBackupAEAD(dataKey)
+	if err != nil {
+		return ErrBackupDecryptFailed
+	}
+
+	buf := make([]byte, header.ChunkSize+aead.Overhead())
+	for counter := uint64(0); ; counter++ {
+		n, err := io.ReadFull(reader, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		final := err != nil
+		if !final {
+			if final, err = atEOF(reader); err != nil {
+				return err
+			}
+		}
+		nonce, aad := backupChunkParams(header, headerBytes, counter, final)
+		plaintext, err := aead.Open(buf[:0], nonce, buf[:n], aad)
+		if err != nil {
+			return ErrBackupDecryptFailed
+		}
+		if _, err := w.Write(plaintext); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+	}
+}
+
+// readEncryptionHeader 读取文件头，返回元数据和原始JSON（作为附加认证数据）
+func readEncryptionHeader(reader *bufio.Reader) (*BackupEncryptionHeader, []byte, error) {
+	magic := make([]byte, len(backupEncryptionMagic))
+	if _, err := io.ReadFull(reader, magic); err != nil || string(magic) != backupEncryptionMagic {
+		return nil, nil, ErrBackupNotEncrypted
+	}
+	var length uint32
+	if err := binary.Read(reader, binary.BigEndian, &length); err != nil || length > 1<<20 {
+		return nil, nil, ErrBackupDecryptFailed
+	}
+	headerBytes := make([]byte, length)
+	if _, err := io.ReadFull(reader, headerBytes); err != nil {
+		return nil, nil, ErrBackupDecryptFailed
+	}
+	var header BackupEncryptionHeader
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return nil, nil, ErrBackupDecryptFailed
+	}
+	return &header, headerBytes, nil
+}
+
+// backupChunkParams 分块的nonce（前缀 + 序号）和附加认证数据（元数据 + 序号 + 是否最后一块）
+func backupChunkParams(header *BackupEncryptionHeader, headerBytes []byte, counter uint64, final bool) ([]byte, []byte) {
+	nonce := make([]byte, 12)
+	copy(nonce, header.NoncePrefix)
+	binary.BigEndian.PutUint64(nonce[4:], counter)
+
+	aad := make([]byte, len(headerBytes)+9)
+	copy(aad, headerBytes)
+	binary.BigEndian.PutUint64(aad[len(headerBytes):], counter)
+	if final {
+		aad[len(aad)-1] = 1
+	}
+	return nonce, aad
+}
+
+// atEOF 是否已读到文件末尾
+func atEOF(reader *bufio.Reader) (bool, error) {
+	if _, err := reader.Peek(1); err != nil {
+		if err == io.EOF {
+			return true, nil
+		}
+		return false, err
+	}
+	return false, nil
+}
+
+// newBackupAEAD 创建AES-256-GCM
+func newBackupAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("密钥长度必须为32字节")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// loadEscrowPublicKey 读取PEM格式的托管公钥（PKIX或PKCS#1）
+func loadEscrowPublicKey(path string) (*rsa.PublicKey, error) {
+	block, err := readPEMFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析托管公钥失败: %v", err)
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("托管公钥必须为RSA公钥")
+	}
+	return key, nil
+}
+
+// escrowKeyID 托管公钥指纹，写入备份元数据，便于找到对应的私钥
+func escrowKeyID(publicKey *rsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// readPEMFile 读取PEM文件的第一个块
+func readPEMFile(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取密钥文件失败: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("密钥文件不是PEM格式: %s", path)
+	}
+	return block, nil
+}
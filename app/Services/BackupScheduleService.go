@@ -0,0 +1,600 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 定时备份的存储位置
+const (
+	BackupTargetLocal   = "local"
+	BackupTargetArchive = "archive"
+)
+
+// 备份运行的触发方式
+const (
+	BackupTriggerSchedule = "schedule"
+	BackupTriggerManual   = "manual"
+)
+
+// 备份运行的阶段
+const (
+	BackupStageQueued    = "queued"
+	BackupStageBackup    = "backup"
+	BackupStageUpload    = "upload"
+	BackupStageRetention = "retention"
+	BackupStageDone      = "done"
+)
+
+var (
+	// ErrBackupScheduleNotFound 定时备份不存在
+	ErrBackupScheduleNotFound = errors.New("定时备份不存在")
+	// ErrInvalidBackupSchedule 定时备份参数无效
+	ErrInvalidBackupSchedule = errors.New("定时备份参数无效")
+	// ErrBackupRunNotFound 备份运行记录不存在
+	ErrBackupRunNotFound = errors.New("备份运行记录不存在")
+	// ErrBackupRunInProgress 定时备份正在运行
+	ErrBackupRunInProgress = errors.New("该定时备份正在运行，请等待运行结束")
+)
+
+// BackupScheduleInput 创建和更新定时备份的参数
+type BackupScheduleInput struct {
+	Name            string   `json:"name"`
+	Type            string   `json:"type"`
+	Tables          []string `json:"tables"`
+	Domains         []string `json:"domains"`
+	Schedule        string   `json:"schedule"`
+	Target          string   `json:"target"`
+	RetentionCount  int      `json:"retention_count"`
+	RetentionDays   int      `json:"retention_days"`
+	NotifyOnSuccess bool     `json:"notify_on_success"`
+	Enabled         *bool    `json:"enabled"`
+}
+
+// BackupScheduleService 定时备份服务
+// 功能说明：
+// 1. 管理多个定时备份计划，每个计划有自己的cron表达式、备份类型、存储位置和保留策略
+// 2. 到期的计划按检查间隔运行，多实例部署时以运行次数抢占，只有一个实例执行
+// 3. 可以手动触发，运行在后台执行，通过运行记录轮询阶段和进度
+// 4. 每次运行保存一条运行记录；失败、失败后恢复时通知管理员，成功时按计划设置通知
+type BackupScheduleService struct {
+	db            *gorm.DB
+	uow           *Database.UnitOfWork
+	backups       *BackupService
+	config        *Config.StorageConfig
+	store         ArchiveObjectStore
+	notifications *NotificationService
+	now           func() time.Time
+}
+
+// NewBackupScheduleService 创建定时备份服务
+func NewBackupScheduleService(db *gorm.DB, backups *BackupService, config *Config.StorageConfig) *BackupScheduleService {
+	if config == nil {
+		config = Config.GetStorageConfig()
+	}
+	if config == nil {
+		config = &Config.StorageConfig{BackupSchedulesEnabled: true, BackupScheduleCheckInterval: time.Minute}
+	}
+	return &BackupScheduleService{
+		db:            db,
+		uow:           Database.NewUnitOfWork(db),
+		backups:       backups,
+		config:        config,
+		notifications: DefaultNotificationService(),
+		now:           time.Now,
+	}
+}
+
+// SetObjectStore 设置上传备份的归档存储，未设置时存储位置只能为 local
+func (s *BackupScheduleService) SetObjectStore(store ArchiveObjectStore) {
+	s.store = store
+}
+
+// SetNotificationService 设置通知管理员的通知服务
+func (s *BackupScheduleService) SetNotificationService(notifications *NotificationService) {
+	s.notifications = notifications
+}
+
+// ListSchedules 获取全部定时备份
+func (s *BackupScheduleService) ListSchedules() ([]Models.BackupSchedule, error) {
+	schedules := make([]Models.BackupSchedule, 0)
+	err := s.db.Order("id ASC").Find(&schedules).Error
+	return schedules, err
+}
+
+// GetSchedule 获取定时备份
+func (s *BackupScheduleService) GetSchedule(id uint) (*Models.BackupSchedule, error) {
+	var schedule Models.BackupSchedule
+	err := s.db.First(&schedule, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBackupScheduleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// CreateSchedule 创建定时备份
+func (s *BackupScheduleService) CreateSchedule(input BackupScheduleInput, createdBy uint) (*Models.BackupSchedule, error) {
+	schedule := &Models.BackupSchedule{CreatedBy: createdBy, Enabled: true}
+	if err := s.applyScheduleInput(schedule, input); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(schedule).Error; err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// UpdateSchedule 更新定时备份，调度表达式变化时重新计算下次运行时间
+func (s *BackupScheduleService) UpdateSchedule(id uint, input BackupScheduleInput) (*Models.BackupSchedule, error) {
+	schedule, err := s.GetSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyScheduleInput(schedule, input); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(schedule).Error; err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// DeleteSchedule 删除定时备份和运行记录，已创建的备份文件保留
+func (s *BackupScheduleService) DeleteSchedule(id uint) error {
+	return s.uow.WithTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+		result := tx.Delete(&Models.BackupSchedule{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrBackupScheduleNotFound
+		}
+		return tx.Where("schedule_id = ?", id).Delete(&Models.BackupRun{}).Error
+	})
+}
+
+// applyScheduleInput 校验参数并写入定时备份
+func (s *BackupScheduleService) applyScheduleInput(schedule *Models.BackupSchedule, input BackupScheduleInput) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len([]rune(name)) > 100 {
+		return fmt.Errorf("%w: 名称不能为空且不能超过100个字符", ErrInvalidBackupSchedule)
+	}
+	backupType := strings.ToLower(strings.TrimSpace(input.Type))
+	if backupType == "" {
+		backupType = "full"
+	}
+	tables, domains := trimList(input.Tables), trimList(input.Domains)
+	switch backupType {
+	case "database", "files", "full":
+		if len(tables) > 0 || len(domains) > 0 {
+			return fmt.Errorf("%w: 只有 tables 类型可以选择表或领域", ErrInvalidBackupSchedule)
+		}
+	case "tables":
+		if len(tables) == 0 && len(domains) == 0 {
+			return fmt.Errorf("%w: %v", ErrInvalidBackupSchedule, ErrBackupSelectionEmpty)
+		}
+		for _, domain := range domains {
+			if _, ok := BackupDomains[domain]; !ok {
+				return fmt.Errorf("%w: %v: %s", ErrInvalidBackupSchedule, ErrUnknownBackupDomain, domain)
+			}
+		}
+	default:
+		return fmt.Errorf("%w: 备份类型应为 database、files、full 或 tables", ErrInvalidBackupSchedule)
+	}
+	target := strings.ToLower(strings.TrimSpace(input.Target))
+	if target == "" {
+		target = BackupTargetLocal
+	}
+	switch target {
+	case BackupTargetLocal:
+	case BackupTargetArchive:
+		if s.store == nil {
+			return fmt.Errorf("%w: 归档存储未启用，存储位置只能为 local", ErrInvalidBackupSchedule)
+		}
+	default:
+		return fmt.Errorf("%w: 存储位置应为 local 或 archive", ErrInvalidBackupSchedule)
+	}
+	if input.RetentionCount < 0 || input.RetentionDays < 0 {
+		return fmt.Errorf("%w: 保留数量和保留天数不能为负数", ErrInvalidBackupSchedule)
+	}
+	expression := strings.TrimSpace(input.Schedule)
+	cron, err := ParseCronSchedule(expression)
+	if err != nil {
+		return fmt.Errorf("%w: 调度表达式无效: %v", ErrInvalidBackupSchedule, err)
+	}
+
+	if schedule.Schedule != expression || schedule.NextRunAt == nil {
+		next := cron.Next(s.now())
+		schedule.NextRunAt = &next
+	}
+	schedule.Name = name
+	schedule.Type = backupType
+	schedule.Tables = strings.Join(tables, ",")
+	schedule.Domains = strings.Join(domains, ",")
+	schedule.Schedule = expression
+	schedule.Target = target
+	schedule.RetentionCount = input.RetentionCount
+	schedule.RetentionDays = input.RetentionDays
+	schedule.NotifyOnSuccess = input.NotifyOnSuccess
+	if input.Enabled != nil {
+		schedule.Enabled = *input.Enabled
+	}
+	return nil
+}
+
+// ListRuns 获取定时备份的运行记录，按时间倒序
+func (s *BackupScheduleService) ListRuns(scheduleID uint, limit int) ([]Models.BackupRun, error) {
+	if _, err := s.GetSchedule(scheduleID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	runs := make([]Models.BackupRun, 0)
+	err := s.db.Where("schedule_id = ?", scheduleID).Order("id DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
+
+// GetRun 获取备份运行记录，用于轮询手动触发的运行进度
+func (s *BackupScheduleService) GetRun(id uint) (*Models.BackupRun, error) {
+	var run Models.BackupRun
+	err := s.db.First(&run, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBackupRunNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// TriggerSchedule 手动触发定时备份，立即返回运行记录，备份在后台执行，不影响下次运行时间
+func (s *BackupScheduleService) TriggerSchedule(id, userID uint) (*Models.BackupRun, error) {
+	schedule, err := s.GetSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+	run, err := s.startRun(schedule, BackupTriggerManual, userID)
+	if err != nil {
+		return nil, err
+	}
+	// 备份耗时较长，不使用请求的上下文；返回副本，后台运行继续更新原记录
+	queued := *run
+	go s.execute(context.Background(), schedule, run)
+	return &queued, nil
+}
+
+// RunDueSchedules 运行到期的定时备份，返回运行的数量
+func (s *BackupScheduleService) RunDueSchedules(ctx context.Context, now time.Time) int {
+	var schedules []Models.BackupSchedule
+	if err := s.db.Where("enabled = ? AND next_run_at <= ?", true, now).Find(&schedules).Error; err != nil {
+		log.Printf("查询到期的定时备份失败: %v", err)
+		return 0
+	}
+
+	ran := 0
+	for i := range schedules {
+		schedule := &schedules[i]
+		cron, err := ParseCronSchedule(schedule.Schedule)
+		if err != nil {
+			log.Printf("定时备份调度表达式无效: schedule=%d, expression=%s, error=%v", schedule.ID, schedule.Schedule, err)
+			continue
+		}
+
+		// 错过的调度只补运行一次，下次运行时间从当前时间起算
+		// 以运行次数作为版本号抢占，多实例部署时只有一个实例运行
+		next := cron.Next(now)
+		claim := s.db.Model(&Models.BackupSchedule{}).
+			Where("id = ? AND run_count = ?", schedule.ID, schedule.RunCount).
+			Updates(map[string]interface{}{"next_run_at": next, "run_count": gorm.Expr("run_count + 1")})
+		if claim.Error != nil || claim.RowsAffected == 0 {
+			continue
+		}
+		schedule.NextRunAt = &next
+		schedule.RunCount++
+
+		run, err := s.startRun(schedule, BackupTriggerSchedule, 0)
+		if err != nil {
+			log.Printf("定时备份未运行: schedule=%d, error=%v", schedule.ID, err)
+			continue
+		}
+		s.execute(ctx, schedule, run)
+		ran++
+	}
+	return ran
+}
+
+// StartScheduler 启动定时备份，按检查间隔运行到期的计划，ctx 取消时停止
+// 启动时将上次进程退出时仍在运行的记录标记为失败
+func (s *BackupScheduleService) StartScheduler(ctx context.Context) {
+	s.failInterruptedRuns()
+	go func() {
+		ticker := time.NewTicker(s.config.BackupScheduleCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.RunDueSchedules(ctx, now)
+			}
+		}
+	}()
+}
+
+// failInterruptedRuns 将超过一天仍未结束的运行记录标记为失败，这些运行所在的进程已经退出
+func (s *BackupScheduleService) failInterruptedRuns() {
+	now := s.now()
+	err := s.db.Model(&Models.BackupRun{}).
+		Where("status IN ? AND created_at < ?", []string{Models.BackupRunPending, Models.BackupRunRunning}, now.Add(-24*time.Hour)).
+		Updates(map[string]interface{}{"status": Models.BackupRunFailed, "error": "运行被中断", "finished_at": now}).Error
+	if err != nil {
+		log.Printf("清理中断的备份运行记录失败: %v", err)
+	}
+}
+
+// startRun 创建运行记录，同一计划有未结束的运行时返回 ErrBackupRunInProgress
+// 事务中先锁定计划记录（SELECT ... FOR UPDATE）再检查和创建，同一计划的并发触发（包括多个实例）排队执行，
+// 后到的触发能看到已创建的运行记录，不会创建多条；SQLite不支持行锁，并发写事务由数据库写锁互斥
+func (s *BackupScheduleService) startRun(schedule *Models.BackupSchedule, trigger string, userID uint) (*Models.BackupRun, error) {
+	var run *Models.BackupRun
+	err := s.uow.WithTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+		var locked Models.BackupSchedule
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&locked, schedule.ID).Error; err != nil {
+			return err
+		}
+
+		var active int64
+		err := tx.Model(&Models.BackupRun{}).
+			Where("schedule_id = ? AND status IN ? AND created_at >= ?", schedule.ID,
+				[]string{Models.BackupRunPending, Models.BackupRunRunning}, s.now().Add(-24*time.Hour)).
+			Count(&active).Error
+		if err != nil {
+			return err
+		}
+		if active > 0 {
+			return ErrBackupRunInProgress
+		}
+		run = &Models.BackupRun{
+			ScheduleID:  schedule.ID,
+			Trigger:     trigger,
+			TriggeredBy: userID,
+			Status:      Models.BackupRunPending,
+			Stage:       BackupStageQueued,
+			Target:      schedule.Target,
+			CreatedAt:   s.now(),
+		}
+		return tx.Create(run).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// execute 创建备份、上传到存储位置并按保留策略清理，记录运行结果并通知管理员
+func (s *BackupScheduleService) execute(ctx context.Context, schedule *Models.BackupSchedule, run *Models.BackupRun) {
+	startedAt := s.now()
+	run.StartedAt = &startedAt
+	s.updateRun(run, map[string]interface{}{"status": Models.BackupRunRunning, "started_at": startedAt}, BackupStageBackup, 10)
+
+	err := s.runBackup(ctx, schedule, run)
+	finishedAt := s.now()
+	run.FinishedAt = &finishedAt
+	run.DurationMS = finishedAt.Sub(startedAt).Milliseconds()
+	run.Status = Models.BackupRunSuccess
+	run.Stage = BackupStageDone
+	run.Progress = 100
+	run.Error = ""
+	if err != nil {
+		run.Status = Models.BackupRunFailed
+		run.Error = truncateString(err.Error(), 997)
+		log.Printf("定时备份失败: schedule=%d, run=%d, error=%v", schedule.ID, run.ID, err)
+		s.backups.publishBackupEvent(nil, err)
+	}
+	recovered := run.Status == Models.BackupRunSuccess && schedule.ConsecutiveFailures > 0
+	updates := map[string]interface{}{
+		"last_run_at": finishedAt,
+		"last_status": run.Status,
+		"last_error":  run.Error,
+	}
+	if run.Status == Models.BackupRunSuccess {
+		schedule.ConsecutiveFailures = 0
+		schedule.LastBackupID = run.BackupID
+		updates["last_backup_id"] = run.BackupID
+	} else {
+		schedule.ConsecutiveFailures++
+	}
+	updates["consecutive_failures"] = schedule.ConsecutiveFailures
+	schedule.LastRunAt = &finishedAt
+	schedule.LastStatus = run.Status
+	schedule.LastError = run.Error
+	// 运行记录和计划的最近结果在同一事务中保存，避免两者不一致；备份被取消时同样需要保存结果
+	err = s.uow.WithTransaction(context.WithoutCancel(ctx), func(ctx context.Context, tx *gorm.DB) error {
+		if err := tx.Model(&Models.BackupRun{}).Where("id = ?", run.ID).Updates(map[string]interface{}{
+			"status":      run.Status,
+			"error":       run.Error,
+			"finished_at": finishedAt,
+			"duration_ms": run.DurationMS,
+			"stage":       run.Stage,
+			"progress":    run.Progress,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Models.BackupSchedule{}).Where("id = ?", schedule.ID).Updates(updates).Error
+	})
+	if err != nil {
+		log.Printf("保存定时备份结果失败: schedule=%d, run=%d, error=%v", schedule.ID, run.ID, err)
+	}
+
+	if run.Status == Models.BackupRunFailed || recovered || schedule.NotifyOnSuccess {
+		s.notify(schedule, run, recovered)
+	}
+}
+
+// runBackup 按计划创建备份，存储位置为 archive 时上传备份和校验和、清单文件，然后按保留策略清理
+func (s *BackupScheduleService) runBackup(ctx context.Context, schedule *Models.BackupSchedule, run *Models.BackupRun) error {
+	var (
+		info *BackupInfo
+		err  error
+	)
+	switch schedule.Type {
+	case "database":
+		info, err = s.backups.CreateDatabaseBackup()
+	case "files":
+		info, err = s.backups.CreateFileBackup()
+	case "full":
+		info, err = s.backups.CreateFullBackup()
+	case "tables":
+		info, err = s.backups.CreateTableBackup(ctx, BackupSelection{
+			Tables:  trimList(strings.Split(schedule.Tables, ",")),
+			Domains: trimList(strings.Split(schedule.Domains, ",")),
+		})
+	default:
+		err = fmt.Errorf("不支持的备份类型: %s", schedule.Type)
+	}
+	if err != nil {
+		return err
+	}
+	run.BackupID = info.ID
+	run.BackupPath = info.Path
+	run.Size = info.Size
+	s.updateRun(run, map[string]interface{}{"backup_id": info.ID, "backup_path": info.Path, "size": info.Size}, BackupStageBackup, 60)
+
+	if schedule.Target == BackupTargetArchive {
+		s.updateRun(run, nil, BackupStageUpload, 70)
+		key, err := s.upload(ctx, schedule, info.Path)
+		if err != nil {
+			return fmt.Errorf("上传备份失败: %v", err)
+		}
+		run.ObjectKey = key
+		s.updateRun(run, map[string]interface{}{"object_key": key}, BackupStageUpload, 85)
+	}
+
+	s.updateRun(run, nil, BackupStageRetention, 90)
+	s.applyRetention(schedule, run)
+	return nil
+}
+
+// upload 上传备份文件及其校验和、清单文件，对象键为 backups/<计划ID>/<文件名>
+func (s *BackupScheduleService) upload(ctx context.Context, schedule *Models.BackupSchedule, backupPath string) (string, error) {
+	if s.store == nil {
+		return "", fmt.Errorf("归档存储未启用")
+	}
+	prefix := path.Join("backups", fmt.Sprint(schedule.ID))
+	for _, sidecar := range []string{backupChecksumExt, backupManifestExt} {
+		data, err := os.ReadFile(backupPath + sidecar)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if err := s.store.Put(ctx, path.Join(prefix, filepath.Base(backupPath)+sidecar), data); err != nil {
+			return "", err
+		}
+	}
+	data, err := os.ReadFile(backupPath)
+	if err != nil {
+		return "", err
+	}
+	key := path.Join(prefix, filepath.Base(backupPath))
+	return key, s.store.Put(ctx, key, data)
+}
+
+// applyRetention 删除该计划超出保留数量或保留天数的备份文件，运行记录保留，文件路径清空
+// 只清理备份目录中的文件，归档存储中的副本由存储的生命周期规则管理
+func (s *BackupScheduleService) applyRetention(schedule *Models.BackupSchedule, current *Models.BackupRun) {
+	if schedule.RetentionCount == 0 && schedule.RetentionDays == 0 {
+		return
+	}
+	var runs []Models.BackupRun
+	err := s.db.Where("schedule_id = ? AND backup_path <> '' AND (status = ? OR id = ?)", schedule.ID, Models.BackupRunSuccess, current.ID).
+		Order("id DESC").Find(&runs).Error
+	if err != nil {
+		log.Printf("查询定时备份的备份文件失败: schedule=%d, error=%v", schedule.ID, err)
+		return
+	}
+	cutoff := s.now().AddDate(0, 0, -schedule.RetentionDays)
+	for i, run := range runs {
+		if run.ID == current.ID {
+			continue
+		}
+		expired := schedule.RetentionDays > 0 && run.CreatedAt.Before(cutoff)
+		if !expired && (schedule.RetentionCount == 0 || i < schedule.RetentionCount) {
+			continue
+		}
+		if err := s.backups.DeleteBackup(run.BackupPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("删除过期备份失败: schedule=%d, path=%s, error=%v", schedule.ID, run.BackupPath, err)
+			continue
+		}
+		s.db.Model(&Models.BackupRun{}).Where("id = ?", run.ID).Update("backup_path", "")
+	}
+}
+
+// updateRun 更新运行记录的阶段、进度和其他字段
+func (s *BackupScheduleService) updateRun(run *Models.BackupRun, updates map[string]interface{}, stage string, progress int) {
+	if updates == nil {
+		updates = map[string]interface{}{}
+	}
+	run.Stage = stage
+	run.Progress = progress
+	updates["stage"] = stage
+	updates["progress"] = progress
+	if err := s.db.Model(&Models.BackupRun{}).Where("id = ?", run.ID).Updates(updates).Error; err != nil {
+		log.Printf("更新备份运行记录失败: run=%d, error=%v", run.ID, err)
+	}
+}
+
+// notify 通知管理员运行结果
+func (s *BackupScheduleService) notify(schedule *Models.BackupSchedule, run *Models.BackupRun, recovered bool) {
+	if s.notifications == nil {
+		return
+	}
+	title := fmt.Sprintf("定时备份「%s」完成", schedule.Name)
+	content := fmt.Sprintf("备份 %s 已完成，大小 %d 字节，耗时 %d 毫秒", run.BackupID, run.Size, run.DurationMS)
+	if recovered {
+		title = fmt.Sprintf("定时备份「%s」已恢复正常", schedule.Name)
+	}
+	if run.Status == Models.BackupRunFailed {
+		title = fmt.Sprintf("定时备份「%s」失败", schedule.Name)
+		content = fmt.Sprintf("第 %d 次连续失败: %s", schedule.ConsecutiveFailures, run.Error)
+	}
+	data := map[string]interface{}{
+		"schedule_id":          schedule.ID,
+		"run_id":               run.ID,
+		"status":               run.Status,
+		"backup_id":            run.BackupID,
+		"consecutive_failures": schedule.ConsecutiveFailures,
+	}
+	if _, err := s.notifications.NotifyAdmins(Models.NotificationTypeBackup, title, content, data); err != nil {
+		log.Printf("发送定时备份通知失败: schedule=%d, error=%v", schedule.ID, err)
+	}
+}
+
+// trimList 去除空白项
+func trimList(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
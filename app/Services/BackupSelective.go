@@ -0,0 +1,845 @@
+package Services
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// backupManifestExt 备份清单文件的扩展名，清单与备份文件同名，加密备份的清单不加密
+const backupManifestExt = ".manifest.json"
+
+// backupTableBatchSize 恢复表数据时每批插入的行数
+const backupTableBatchSize = 500
+
+// BackupDomains 逻辑领域包含的表，以 * 结尾的为表名前缀
+// 选择领域时只包含备份或数据库中实际存在的表
+var BackupDomains = map[string][]string{
+	"users":      {"users", "user_settings", "user_behavior_profiles", "password_history", "account_lockouts", "impersonation_sessions", "organizations", "teams", "team_members", "team_invitations"},
+	"security":   {"security_*", "login_attempts", "audit_logs", "access_controls", "account_lockouts", "threat_intelligence", "password_history"},
+	"monitoring": {"monitoring_*", "metric_samples", "slow_query_stats"},
+	"content":    {"posts", "categories", "tags", "post_tags", "comments"},
+	"messaging":  {"notifications", "mail_messages", "mail_suppressions", "sms_messages", "sms_templates", "webhook_subscriptions", "webhook_deliveries", "outbox_events"},
+	"billing":    {"billing_*", "usage_records", "usage_quotas"},
+}
+
+var (
+	// ErrBackupNotFound 备份不存在
+	ErrBackupNotFound = errors.New("备份不存在")
+	// ErrBackupSelectionEmpty 没有选择表或领域
+	ErrBackupSelectionEmpty = errors.New("请选择要备份或恢复的表或领域")
+	// ErrUnknownBackupDomain 领域不存在
+	ErrUnknownBackupDomain = errors.New("备份领域不存在")
+	// ErrUnknownBackupTable 表不存在
+	ErrUnknownBackupTable = errors.New("表不存在")
+)
+
+// BackupSelection 按表或逻辑领域选择备份和恢复的内容
+type BackupSelection struct {
+	Tables  []string `json:"tables"`
+	Domains []string `json:"domains"`
+}
+
+// IsEmpty 是否未选择任何表或领域
+func (s BackupSelection) IsEmpty() bool {
+	return len(s.Tables) == 0 && len(s.Domains) == 0
+}
+
+// BackupManifest 备份清单，描述备份包含的表、行数和文件数
+type BackupManifest struct {
+	BackupID  string                `json:"backup_id"`
+	Type      string                `json:"type"`
+	Format    string                `json:"format"` // 数据库备份为驱动名（sqlite为数据库文件，mysql/postgres为SQL），按表备份为 jsonl
+	Driver    string                `json:"driver,omitempty"`
+	Domains   []string              `json:"domains,omitempty"`
+	Tables    []BackupManifestTable `json:"tables,omitempty"`
+	Files     int                   `json:"files,omitempty"` // 文件备份和完整备份中的存储文件数
+	CreatedAt time.Time             `json:"created_at"`
+}
+
+// BackupManifestTable 清单中的表
+type BackupManifestTable struct {
+	Name    string                 `json:"name"`
+	Rows    int64                  `json:"rows"`
+	Domains []string               `json:"domains,omitempty"`
+	Columns []BackupManifestColumn `json:"columns,omitempty"` // 按表备份时记录，恢复时按类型还原
+}
+
+// BackupManifestColumn 按表备份的列
+type BackupManifestColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // int、float、bool、time、bytes、string
+}
+
+// SelectiveRestoreResult 按表恢复的结果
+type SelectiveRestoreResult struct {
+	BackupID   string           `json:"backup_id"`
+	Tables     map[string]int64 `json:"tables"` // 恢复的表和行数
+	DurationMS int64            `json:"duration_ms"`
+}
+
+// SetDB 设置按表备份和恢复使用的数据库连接，未设置时按数据库配置临时连接
+func (s *BackupService) SetDB(db *gorm.DB) {
+	s.db = db
+}
+
+// ListBackupDomains 当前数据库中每个领域包含的表
+func (s *BackupService) ListBackupDomains() (map[string][]string, error) {
+	db, closeDB, err := s.openDatabase()
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB()
+	tables, err := listTables(db)
+	if err != nil {
+		return nil, err
+	}
+	domains := make(map[string][]string, len(BackupDomains))
+	for domain := range BackupDomains {
+		domains[domain] = domainTables(domain, tables)
+	}
+	return domains, nil
+}
+
+// CreateTableBackup 按表或领域创建备份
+// 功能说明：
+// 1. 逐表导出为 JSON Lines（每行一个值数组），列名和类型记录在清单中，与数据库驱动无关
+// 2. 在只读事务中导出，各表数据属于同一时刻
+// 3. 压缩包中包含 manifest.json，备份旁另写同名清单文件；加密和校验和与其他备份相同
+func (s *BackupService) CreateTableBackup(ctx context.Context, selection BackupSelection) (*BackupInfo, error) {
+	if selection.IsEmpty() {
+		return nil, ErrBackupSelectionEmpty
+	}
+	db, closeDB, err := s.openDatabase()
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB()
+	available, err := listTables(db)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := resolveBackupSelection(selection, available)
+	if err != nil {
+		return nil, err
+	}
+
+	backupID := fmt.Sprintf("tables_%s", time.Now().Format("20060102_150405"))
+	backupPath := filepath.Join(s.backupPath, backupID+".zip")
+	backupInfo := &BackupInfo{
+		ID:        backupID,
+		Type:      "tables",
+		Path:      backupPath,
+		CreatedAt: time.Now(),
+		Status:    "in_progress",
+		Metadata:  make(map[string]interface{}),
+	}
+	manifest := &BackupManifest{
+		BackupID:  backupID,
+		Type:      "tables",
+		Format:    "jsonl",
+		Driver:    s.databaseConfig().Driver,
+		Domains:   selection.Domains,
+		CreatedAt: backupInfo.CreatedAt,
+	}
+
+	if err := s.writeTableArchive(ctx, db, backupPath, tables, manifest); err != nil {
+		os.Remove(backupPath)
+		s.storageManager.LogError("按表备份失败", map[string]interface{}{
+			"backup_id": backupID,
+			"tables":    tables,
+			"error":     err.Error(),
+		})
+		return nil, err
+	}
+
+	fileInfo, err := os.Stat(backupPath)
+	if err != nil {
+		return nil, err
+	}
+	backupInfo.Size = fileInfo.Size()
+	backupInfo.Status = "success"
+	backupInfo.Description = "按表备份成功"
+	if backupInfo.MD5, err = s.calculateMD5(backupPath); err != nil {
+		return nil, err
+	}
+	if err := s.encryptBackup(backupInfo); err != nil {
+		return nil, err
+	}
+	if err := s.writeChecksum(backupInfo); err != nil {
+		return nil, err
+	}
+	s.saveManifest(backupInfo, manifest)
+
+	s.storageManager.LogInfo("按表备份成功", map[string]interface{}{
+		"backup_id": backupID,
+		"path":      backupInfo.Path,
+		"tables":    tables,
+		"size":      backupInfo.Size,
+	})
+	s.publishBackupEvent(backupInfo, nil)
+	return backupInfo, nil
+}
+
+// ReadBackupManifest 读取备份清单
+func (s *BackupService) ReadBackupManifest(backupPath string) (*BackupManifest, error) {
+	data, err := os.ReadFile(backupPath + backupManifestExt)
+	if err != nil {
+		return nil, err
+	}
+	var manifest BackupManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("备份清单格式错误: %v", err)
+	}
+	return &manifest, nil
+}
+
+// RestoreSelected 从备份中恢复选择的表，其他表不受影响
+// 功能说明：
+// 1. 按表备份直接读取压缩包中的表数据；未选择时恢复备份中的全部表
+// 2. 数据库备份和完整备份先恢复到临时数据库（与恢复演练相同），再复制选择的表，必须选择表或领域
+// 3. 在一个事务中清空并写入选择的表，任一表失败时全部回滚
+// 4. 只写入当前表中仍存在的列，备份后新增的列使用默认值
+func (s *BackupService) RestoreSelected(ctx context.Context, backupPath string, selection BackupSelection) (*SelectiveRestoreResult, error) {
+	startedAt := time.Now()
+	if err := s.validateBackup(backupPath); err != nil {
+		return nil, fmt.Errorf("备份文件验证失败: %w", err)
+	}
+	tempDir, err := os.MkdirTemp(s.backupPath, "restore_")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	sourcePath := backupPath
+	if IsEncryptedBackup(sourcePath) {
+		if sourcePath, err = s.decryptBackupInto(ctx, sourcePath, tempDir); err != nil {
+			return nil, fmt.Errorf("备份解密失败: %w", err)
+		}
+	}
+
+	target, closeTarget, err := s.openDatabase()
+	if err != nil {
+		return nil, err
+	}
+	defer closeTarget()
+
+	result := &SelectiveRestoreResult{
+		BackupID: strings.SplitN(filepath.Base(backupPath), ".", 2)[0],
+		Tables:   make(map[string]int64),
+	}
+	if strings.HasPrefix(filepath.Base(backupPath), "tables_") {
+		err = s.restoreFromTableArchive(ctx, target, sourcePath, selection, result)
+	} else {
+		err = s.restoreFromDatabaseDump(ctx, target, sourcePath, tempDir, selection, result)
+	}
+	result.DurationMS = time.Since(startedAt).Milliseconds()
+	if err != nil {
+		s.storageManager.LogError("按表恢复失败", map[string]interface{}{
+			"backup_id": result.BackupID,
+			"error":     err.Error(),
+		})
+		return nil, err
+	}
+	s.storageManager.LogInfo("按表恢复成功", map[string]interface{}{
+		"backup_id":   result.BackupID,
+		"tables":      result.Tables,
+		"duration_ms": result.DurationMS,
+	})
+	return result, nil
+}
+
+// restoreFromTableArchive 从按表备份恢复
+func (s *BackupService) restoreFromTableArchive(ctx context.Context, target *gorm.DB, archivePath string, selection BackupSelection, result *SelectiveRestoreResult) error {
+	archive, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("打开备份文件失败: %v", err)
+	}
+	defer archive.Close()
+
+	manifest, err := readArchiveManifest(archive)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]BackupManifestTable, len(manifest.Tables))
+	contained := make([]string, 0, len(manifest.Tables))
+	for _, table := range manifest.Tables {
+		byName[table.Name] = table
+		contained = append(contained, table.Name)
+	}
+	tables := contained
+	if !selection.IsEmpty() {
+		if tables, err = resolveBackupSelection(selection, contained); err != nil {
+			return err
+		}
+	}
+	if err := requireTables(target, tables); err != nil {
+		return err
+	}
+
+	return replaceTables(ctx, target, tables, func(table string, load func(columns []string, next func() ([]interface{}, error)) error) error {
+		entry, err := archive.Open("tables/" + table + ".jsonl")
+		if err != nil {
+			return fmt.Errorf("备份中缺少表 %s 的数据: %v", table, err)
+		}
+		defer entry.Close()
+
+		columns := byName[table].Columns
+		names := make([]string, len(columns))
+		for i, column := range columns {
+			names[i] = column.Name
+		}
+		scanner := bufio.NewScanner(entry)
+		scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+		return load(names, func() ([]interface{}, error) {
+			if !scanner.Scan() {
+				if err := scanner.Err(); err != nil {
+					return nil, err
+				}
+				return nil, io.EOF
+			}
+			return decodeBackupRow(scanner.Bytes(), columns)
+		})
+	}, result)
+}
+
+// restoreFromDatabaseDump 从数据库备份或完整备份恢复：先恢复到临时数据库，再复制选择的表
+func (s *BackupService) restoreFromDatabaseDump(ctx context.Context, target *gorm.DB, sourcePath, tempDir string, selection BackupSelection, result *SelectiveRestoreResult) error {
+	if selection.IsEmpty() {
+		return ErrBackupSelectionEmpty
+	}
+	dumpPath, err := extractDatabaseDump(sourcePath, tempDir)
+	if err != nil {
+		return err
+	}
+	staging, cleanup, err := s.openDrillDatabase(dumpPath, tempDir)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	contained, err := listTables(staging)
+	if err != nil {
+		return err
+	}
+	tables, err := resolveBackupSelection(selection, contained)
+	if err != nil {
+		return err
+	}
+	if err := requireTables(target, tables); err != nil {
+		return err
+	}
+
+	return replaceTables(ctx, target, tables, func(table string, load func(columns []string, next func() ([]interface{}, error)) error) error {
+		rows, err := staging.WithContext(ctx).Table(table).Rows()
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		columns, err := rows.Columns()
+		if err != nil {
+			return err
+		}
+		return load(columns, func() ([]interface{}, error) {
+			if !rows.Next() {
+				if err := rows.Err(); err != nil {
+					return nil, err
+				}
+				return nil, io.EOF
+			}
+			return scanRow(rows, len(columns))
+		})
+	}, result)
+}
+
+// replaceTables 在一个事务中清空并写入各表
+// source 打开一个表的数据，通过 load 传入列名和逐行读取函数（读完返回 io.EOF）
+func replaceTables(ctx context.Context, target *gorm.DB, tables []string, source func(table string, load func(columns []string, next func() ([]interface{}, error)) error) error, result *SelectiveRestoreResult) error {
+	dialect := target.Dialector.Name()
+	return target.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if dialect == "mysql" {
+			// 恢复部分表时其他表的外键引用可能暂时不一致
+			if err := tx.Exec("SET FOREIGN_KEY_CHECKS = 0").Error; err != nil {
+				return err
+			}
+			defer tx.Exec("SET FOREIGN_KEY_CHECKS = 1")
+		}
+		for _, table := range tables {
+			targetColumns, err := tx.Migrator().ColumnTypes(table)
+			if err != nil {
+				return fmt.Errorf("读取表 %s 的列失败: %v", table, err)
+			}
+			existing := make(map[string]bool, len(targetColumns))
+			for _, column := range targetColumns {
+				existing[column.Name()] = true
+			}
+			if err := tx.Exec("DELETE FROM " + tx.Statement.Quote(table)).Error; err != nil {
+				return fmt.Errorf("清空表 %s 失败: %v", table, err)
+			}
+
+			var restored int64
+			err = source(table, func(columns []string, next func() ([]interface{}, error)) error {
+				batch := make([]map[string]interface{}, 0, backupTableBatchSize)
+				flush := func() error {
+					if len(batch) == 0 {
+						return nil
+					}
+					if err := tx.Table(table).Create(&batch).Error; err != nil {
+						return err
+					}
+					restored += int64(len(batch))
+					batch = batch[:0]
+					return nil
+				}
+				for {
+					values, err := next()
+					if err == io.EOF {
+						return flush()
+					}
+					if err != nil {
+						return err
+					}
+					row := make(map[string]interface{}, len(columns))
+					for i, column := range columns {
+						if existing[column] && i < len(values) {
+							row[column] = values[i]
+						}
+					}
+					batch = append(batch, row)
+					if len(batch) == backupTableBatchSize {
+						if err := flush(); err != nil {
+							return err
+						}
+					}
+				}
+			})
+			if err != nil {
+				return fmt.Errorf("恢复表 %s 失败: %v", table, err)
+			}
+			if dialect == "postgres" && existing["id"] {
+				// 写入了显式ID，序列需要跟上最大ID
+				quoted := tx.Statement.Quote(table)
+				if err := tx.Exec(fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%s', 'id'), COALESCE(MAX(id), 1)) FROM %s", table, quoted)).Error; err != nil {
+					return fmt.Errorf("重置表 %s 的序列失败: %v", table, err)
+				}
+			}
+			result.Tables[table] = restored
+		}
+		return nil
+	})
+}
+
+// writeTableArchive 在只读事务中导出各表并写入压缩包
+func (s *BackupService) writeTableArchive(ctx context.Context, db *gorm.DB, archivePath string, tables []string, manifest *BackupManifest) error {
+	file, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	archive := zip.NewWriter(file)
+	defer archive.Close()
+
+	options := &sql.TxOptions{ReadOnly: true}
+	if db.Dialector.Name() != "sqlite" {
+		options.Isolation = sql.LevelRepeatableRead
+	}
+	tx := db.WithContext(ctx).Begin(options)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	defer tx.Rollback()
+
+	for _, table := range tables {
+		entry, err := archive.Create("tables/" + table + ".jsonl")
+		if err != nil {
+			return err
+		}
+		described, err := exportTable(tx, table, entry)
+		if err != nil {
+			return fmt.Errorf("导出表 %s 失败: %v", table, err)
+		}
+		described.Domains = tableDomains(table)
+		manifest.Tables = append(manifest.Tables, *described)
+	}
+
+	entry, err := archive.Create("manifest.json")
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return err
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// exportTable 导出一个表，每行写入一个值数组
+func exportTable(tx *gorm.DB, table string, w io.Writer) (*BackupManifestTable, error) {
+	rows, err := tx.Table(table).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	described := &BackupManifestTable{Name: table, Columns: make([]BackupManifestColumn, len(columnTypes))}
+	for i, columnType := range columnTypes {
+		described.Columns[i] = BackupManifestColumn{Name: columnType.Name(), Type: backupColumnType(columnType.DatabaseTypeName())}
+	}
+
+	writer := bufio.NewWriter(w)
+	encoder := json.NewEncoder(writer)
+	for rows.Next() {
+		values, err := scanRow(rows, len(columnTypes))
+		if err != nil {
+			return nil, err
+		}
+		for i, value := range values {
+			values[i] = encodeBackupValue(described.Columns[i].Type, value)
+		}
+		if err := encoder.Encode(values); err != nil {
+			return nil, err
+		}
+		described.Rows++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return described, writer.Flush()
+}
+
+// buildManifest 生成数据库备份、文件备份和完整备份的清单，在加密前调用；无法连接数据库时清单不含表
+func (s *BackupService) buildManifest(backupInfo *BackupInfo) *BackupManifest {
+	driver := s.databaseConfig().Driver
+	manifest := &BackupManifest{
+		BackupID:  backupInfo.ID,
+		Type:      backupInfo.Type,
+		Format:    driver,
+		Driver:    driver,
+		CreatedAt: backupInfo.CreatedAt,
+	}
+	if backupInfo.Type == "files" {
+		manifest.Format = "zip"
+		manifest.Driver = ""
+	}
+	if backupInfo.Type == "database" || backupInfo.Type == "full" {
+		if tables, err := s.describeDatabase(); err == nil {
+			manifest.Tables = tables
+		} else {
+			s.storageManager.LogWarning("备份清单未包含表信息", map[string]interface{}{
+				"backup_id": backupInfo.ID,
+				"error":     err.Error(),
+			})
+		}
+	}
+	if strings.HasSuffix(backupInfo.Path, ".zip") {
+		if archive, err := zip.OpenReader(backupInfo.Path); err == nil {
+			for _, file := range archive.File {
+				if strings.HasPrefix(file.Name, "storage/") && !file.FileInfo().IsDir() {
+					manifest.Files++
+				}
+			}
+			archive.Close()
+		}
+	}
+	return manifest
+}
+
+// saveManifest 写入与备份同名的清单文件
+func (s *BackupService) saveManifest(backupInfo *BackupInfo, manifest *BackupManifest) {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err == nil {
+		err = os.WriteFile(backupInfo.Path+backupManifestExt, data, 0644)
+	}
+	if err != nil {
+		s.storageManager.LogWarning("写入备份清单失败", map[string]interface{}{
+			"backup_id": backupInfo.ID,
+			"error":     err.Error(),
+		})
+		return
+	}
+	backupInfo.Manifest = manifest
+}
+
+// describeDatabase 当前数据库的表和行数
+func (s *BackupService) describeDatabase() ([]BackupManifestTable, error) {
+	db, closeDB, err := s.openDatabase()
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB()
+	tables, err := listTables(db)
+	if err != nil {
+		return nil, err
+	}
+	described := make([]BackupManifestTable, 0, len(tables))
+	for _, table := range tables {
+		var rows int64
+		if err := db.Table(table).Count(&rows).Error; err != nil {
+			return nil, err
+		}
+		described = append(described, BackupManifestTable{Name: table, Rows: rows, Domains: tableDomains(table)})
+	}
+	return described, nil
+}
+
+// openDatabase 按表备份和恢复使用的数据库连接，返回的函数关闭临时连接
+func (s *BackupService) openDatabase() (*gorm.DB, func(), error) {
+	if s.db != nil {
+		return s.db, func() {}, nil
+	}
+	dbConfig := s.databaseConfig()
+	var dialector gorm.Dialector
+	switch dbConfig.Driver {
+	case "sqlite":
+		dialector = sqlite.Open(dbConfig.Database)
+	case "mysql", "postgres":
+		dialector = drillDialector(dbConfig)
+	default:
+		return nil, nil, fmt.Errorf("不支持的数据库驱动: %s", dbConfig.Driver)
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return nil, nil, fmt.Errorf("连接数据库失败: %v", err)
+	}
+	return db, func() { closeGormDB(db) }, nil
+}
+
+// resolveBackupSelection 把选择的表和领域解析为 available 中的表
+// 直接指定的表必须存在；领域只包含存在的表，但至少要匹配到一个表
+func resolveBackupSelection(selection BackupSelection, available []string) ([]string, error) {
+	exists := make(map[string]bool, len(available))
+	for _, table := range available {
+		exists[table] = true
+	}
+	selected := make(map[string]bool)
+	for _, domain := range selection.Domains {
+		domain = strings.TrimSpace(domain)
+		if _, ok := BackupDomains[domain]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownBackupDomain, domain)
+		}
+		for _, table := range domainTables(domain, available) {
+			selected[table] = true
+		}
+	}
+	for _, table := range selection.Tables {
+		table = strings.TrimSpace(table)
+		if !exists[table] {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownBackupTable, table)
+		}
+		selected[table] = true
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("%w: 选择的领域中没有表", ErrUnknownBackupTable)
+	}
+	tables := make([]string, 0, len(selected))
+	for table := range selected {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	return tables, nil
+}
+
+// domainTables 领域在 available 中匹配到的表
+func domainTables(domain string, available []string) []string {
+	var tables []string
+	for _, table := range available {
+		if tableInDomain(table, domain) {
+			tables = append(tables, table)
+		}
+	}
+	return tables
+}
+
+// tableDomains 表所属的领域
+func tableDomains(table string) []string {
+	var domains []string
+	for domain := range BackupDomains {
+		if tableInDomain(table, domain) {
+			domains = append(domains, domain)
+		}
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+// tableInDomain 表是否属于领域
+func tableInDomain(table, domain string) bool {
+	for _, pattern := range BackupDomains[domain] {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(table, prefix) {
+				return true
+			}
+		} else if table == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// listTables 数据库中的表，不含SQLite内部表
+func listTables(db *gorm.DB) ([]string, error) {
+	all, err := db.Migrator().GetTables()
+	if err != nil {
+		return nil, err
+	}
+	tables := make([]string, 0, len(all))
+	for _, table := range all {
+		if !strings.HasPrefix(table, "sqlite_") {
+			tables = append(tables, table)
+		}
+	}
+	sort.Strings(tables)
+	return tables, nil
+}
+
+// requireTables 恢复的表必须已存在于当前数据库（由迁移创建）
+func requireTables(db *gorm.DB, tables []string) error {
+	for _, table := range tables {
+		if !db.Migrator().HasTable(table) {
+			return fmt.Errorf("%w: 当前数据库中没有表 %s，请先执行迁移", ErrUnknownBackupTable, table)
+		}
+	}
+	return nil
+}
+
+// readArchiveManifest 读取按表备份压缩包中的清单
+func readArchiveManifest(archive *zip.ReadCloser) (*BackupManifest, error) {
+	entry, err := archive.Open("manifest.json")
+	if err != nil {
+		return nil, fmt.Errorf("备份中缺少清单: %v", err)
+	}
+	defer entry.Close()
+	var manifest BackupManifest
+	if err := json.NewDecoder(entry).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("备份清单格式错误: %v", err)
+	}
+	return &manifest, nil
+}
+
+// scanRow 读取一行的全部列
+func scanRow(rows *sql.Rows, n int) ([]interface{}, error) {
+	values := make([]interface{}, n)
+	pointers := make([]interface{}, n)
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	if err := rows.Scan(pointers...); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// backupColumnType 按数据库类型名归类列，决定JSON中的编码方式
+func backupColumnType(databaseType string) string {
+	t := strings.ToUpper(databaseType)
+	switch {
+	case strings.Contains(t, "BOOL"):
+		return "bool"
+	case t == "INTEGER" || (strings.HasPrefix(t, "INT") && !strings.HasPrefix(t, "INTERVAL")) || strings.HasSuffix(t, "INT") || strings.Contains(t, "SERIAL"):
+		return "int"
+	case strings.Contains(t, "FLOAT") || strings.Contains(t, "DOUBLE") || t == "REAL":
+		return "float"
+	case strings.Contains(t, "DATE") || strings.Contains(t, "TIME"):
+		return "time"
+	case strings.Contains(t, "BLOB") || strings.Contains(t, "BINARY") || t == "BYTEA":
+		return "bytes"
+	default:
+		// 文本、JSON和DECIMAL等按字符串保存，避免精度损失
+		return "string"
+	}
+}
+
+// encodeBackupValue 把扫描到的值转为JSON可表示的值
+func encodeBackupValue(columnType string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case []byte:
+		if columnType == "bytes" {
+			return base64.StdEncoding.EncodeToString(v)
+		}
+		return string(v)
+	case string:
+		if columnType == "bytes" {
+			return base64.StdEncoding.EncodeToString([]byte(v))
+		}
+	}
+	return value
+}
+
+// decodeBackupRow 按列类型还原一行
+func decodeBackupRow(line []byte, columns []BackupManifestColumn) ([]interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(string(line)))
+	decoder.UseNumber()
+	var values []interface{}
+	if err := decoder.Decode(&values); err != nil {
+		return nil, fmt.Errorf("备份数据格式错误: %v", err)
+	}
+	for i := range values {
+		if i >= len(columns) || values[i] == nil {
+			continue
+		}
+		value, err := decodeBackupValue(columns[i].Type, values[i])
+		if err != nil {
+			return nil, fmt.Errorf("列 %s: %v", columns[i].Name, err)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// decodeBackupValue 按列类型还原一个值
+func decodeBackupValue(columnType string, value interface{}) (interface{}, error) {
+	switch columnType {
+	case "int":
+		if number, ok := value.(json.Number); ok {
+			return number.Int64()
+		}
+	case "float":
+		if number, ok := value.(json.Number); ok {
+			return number.Float64()
+		}
+	case "bool":
+		if number, ok := value.(json.Number); ok {
+			return number.String() != "0", nil
+		}
+	case "time":
+		if text, ok := value.(string); ok {
+			if t, err := time.Parse(time.RFC3339Nano, text); err == nil {
+				return t, nil
+			}
+		}
+	case "bytes":
+		if text, ok := value.(string); ok {
+			return base64.StdEncoding.DecodeString(text)
+		}
+	}
+	if number, ok := value.(json.Number); ok {
+		return number.String(), nil
+	}
+	return value, nil
+}
@@ -3,8 +3,10 @@ package Services
 import (
 	"archive/zip"
 	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
 	"cloud-platform-api/app/Storage"
 	"compress/gzip"
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/json"
@@ -14,7 +16,10 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // BackupConfig 备份配置
@@ -27,6 +32,44 @@ type BackupConfig struct {
 	EnableCompression   bool          `json:"enable_compression"`    // 启用压缩
 	EnableEncryption    bool          `json:"enable_encryption"`     // 启用加密
 	EncryptionKey       string        `json:"encryption_key"`        // 加密密钥
+
+	EncryptionKeyProvider  string   `json:"encryption_key_provider"` // 主密钥来源：local 或 vault
+	EncryptionKeyVersion   string   `json:"encryption_key_version"`  // 本地主密钥版本
+	EncryptionPreviousKeys []string `json:"-"`                       // 轮换前的本地主密钥，格式 版本:密钥
+	VaultAddress           string   `json:"vault_address"`           // Vault地址
+	VaultToken             string   `json:"-"`                       // Vault令牌
+	VaultTransitKey        string   `json:"vault_transit_key"`       // Vault Transit密钥名
+	EscrowPublicKey        string   `json:"escrow_public_key"`       // 托管公钥PEM文件
+
+	RestoreDrillEnabled  bool          `json:"restore_drill_enabled"`  // 启用定时恢复演练
+	RestoreDrillInterval time.Duration `json:"restore_drill_interval"` // 恢复演练间隔
+	RestoreDrillTables   []string      `json:"restore_drill_tables"`   // 恢复演练冒烟查询的表
+}
+
+// NewBackupConfigFromStorage 按存储配置创建备份配置
+func NewBackupConfigFromStorage(storageConfig *Config.StorageConfig) *BackupConfig {
+	return &BackupConfig{
+		EnableAutoBackup:    storageConfig.EnableAutoBackup,
+		BackupInterval:      time.Duration(storageConfig.BackupInterval) * time.Minute,
+		MaxBackupFiles:      storageConfig.MaxBackupFiles,
+		BackupRetentionDays: storageConfig.BackupRetentionDays,
+		BackupPath:          storageConfig.BackupPath,
+		EnableCompression:   storageConfig.EnableCompression,
+		EnableEncryption:    storageConfig.EnableEncryption,
+		EncryptionKey:       storageConfig.EncryptionKey,
+
+		EncryptionKeyProvider:  storageConfig.EncryptionKeyProvider,
+		EncryptionKeyVersion:   storageConfig.EncryptionKeyVersion,
+		EncryptionPreviousKeys: storageConfig.EncryptionPreviousKeys,
+		VaultAddress:           storageConfig.EncryptionVaultAddress,
+		VaultToken:             storageConfig.EncryptionVaultToken,
+		VaultTransitKey:        storageConfig.EncryptionVaultKey,
+		EscrowPublicKey:        storageConfig.BackupEscrowPublicKey,
+
+		RestoreDrillEnabled:  storageConfig.RestoreDrillEnabled,
+		RestoreDrillInterval: time.Duration(storageConfig.RestoreDrillInterval) * time.Hour,
+		RestoreDrillTables:   storageConfig.RestoreDrillTables,
+	}
 }
 
 // BackupInfo 备份信息
@@ -36,10 +79,12 @@ type BackupInfo struct {
 	Path        string                 `json:"path"`
 	Size        int64                  `json:"size"`
 	MD5         string                 `json:"md5"`
+	SHA256      string                 `json:"sha256"` // 备份文件的SHA-256，保存在同名的 .sha256 文件中，恢复前校验
 	CreatedAt   time.Time              `json:"created_at"`
 	Status      string                 `json:"status"` // "success", "failed", "in_progress"
 	Description string                 `json:"description"`
 	Metadata    map[string]interface{} `json:"metadata"`
+	Manifest    *BackupManifest        `json:"manifest,omitempty"` // 备份清单，早于清单功能的备份为空
 }
 
 // BackupService 备份服务
@@ -47,6 +92,13 @@ type BackupService struct {
 	storageManager *Storage.StorageManager
 	config         *BackupConfig
 	backupPath     string
+	dbConfig       *Config.DatabaseConfig
+	db             *gorm.DB          // 按表备份和恢复使用的连接，为空时按数据库配置临时连接
+	keyProvider    BackupKeyProvider // 备份主密钥，未启用加密时为 nil
+	keyProviderErr error             // 按配置创建主密钥失败的原因，加密备份时返回
+
+	drillMu   sync.RWMutex
+	lastDrill *RestoreDrillResult
 }
 
 // NewBackupService 创建备份服务
@@ -81,14 +133,41 @@ func NewBackupService(storageManager *Storage.StorageManager, config *BackupConf
 		backupPath:     config.BackupPath,
 	}
 
+	// 启用加密时按配置创建主密钥，配置错误在备份时报告
+	if config.EnableEncryption {
+		service.keyProvider, service.keyProviderErr = NewBackupKeyProvider(config)
+	}
+
 	// 启动自动备份
 	if config.EnableAutoBackup {
 		go service.startAutoBackup()
 	}
 
+	// 加载上次恢复演练的结果并启动定时演练
+	service.loadLastDrill()
+	if config.RestoreDrillEnabled && config.RestoreDrillInterval > 0 {
+		go service.startRestoreDrills()
+	}
+
 	return service
 }
 
+// SetDatabaseConfig 设置备份和恢复的数据库，未设置时使用全局配置中的数据库
+func (s *BackupService) SetDatabaseConfig(config *Config.DatabaseConfig) {
+	s.dbConfig = config
+}
+
+// databaseConfig 备份和恢复的数据库配置
+func (s *BackupService) databaseConfig() Config.DatabaseConfig {
+	if s.dbConfig != nil {
+		return *s.dbConfig
+	}
+	if globalConfig := Config.GetConfig(); globalConfig != nil {
+		return globalConfig.Database
+	}
+	return Config.DatabaseConfig{}
+}
+
 // CreateDatabaseBackup 创建数据库备份
 // 功能说明：
 // 1. 备份数据库结构和数据
@@ -111,7 +190,7 @@ func (s *BackupService) CreateDatabaseBackup() (*BackupInfo, error) {
 	}
 
 	// 根据数据库类型执行备份
-	dbConfig := Config.GetConfig().Database
+	dbConfig := s.databaseConfig()
 	var err error
 
 	switch dbConfig.Driver {
@@ -167,6 +246,16 @@ func (s *BackupService) CreateDatabaseBackup() (*BackupInfo, error) {
 		}
 	}
 
+	// 加密备份文件，再对密文写入校验和文件，恢复和恢复演练前校验；清单在加密前生成
+	manifest := s.buildManifest(backupInfo)
+	if err := s.encryptBackup(backupInfo); err != nil {
+		return nil, err
+	}
+	if err := s.writeChecksum(backupInfo); err != nil {
+		return nil, err
+	}
+	s.saveManifest(backupInfo, manifest)
+
 	// 记录备份成功日志
 	s.storageManager.LogInfo("数据库备份成功", map[string]interface{}{
 		"backup_id": backupID,
@@ -175,6 +264,7 @@ func (s *BackupService) CreateDatabaseBackup() (*BackupInfo, error) {
 		"md5":       backupInfo.MD5,
 	})
 
+	s.publishBackupEvent(backupInfo, nil)
 	return backupInfo, nil
 }
 
@@ -218,6 +308,12 @@ func (s *BackupService) CreateFileBackup() (*BackupInfo, error) {
 		return backupInfo, err
 	}
 
+	// 关闭压缩包后再计算大小和校验和
+	if err := zipWriter.Close(); err != nil {
+		return nil, err
+	}
+	zipFile.Close()
+
 	// 获取文件信息
 	fileInfo, err := os.Stat(backupPath)
 	if err != nil {
@@ -234,6 +330,14 @@ func (s *BackupService) CreateFileBackup() (*BackupInfo, error) {
 		return nil, err
 	}
 	backupInfo.MD5 = md5Hash
+	manifest := s.buildManifest(backupInfo)
+	if err := s.encryptBackup(backupInfo); err != nil {
+		return nil, err
+	}
+	if err := s.writeChecksum(backupInfo); err != nil {
+		return nil, err
+	}
+	s.saveManifest(backupInfo, manifest)
 
 	// 记录备份成功日志
 	s.storageManager.LogInfo("文件备份成功", map[string]interface{}{
@@ -243,6 +347,7 @@ func (s *BackupService) CreateFileBackup() (*BackupInfo, error) {
 		"md5":       backupInfo.MD5,
 	})
 
+	s.publishBackupEvent(backupInfo, nil)
 	return backupInfo, nil
 }
 
@@ -325,6 +430,12 @@ func (s *BackupService) CreateFullBackup() (*BackupInfo, error) {
 	// 清理临时文件
 	os.Remove(dbBackupPath)
 
+	// 关闭压缩包后再计算大小和校验和
+	if err := zipWriter.Close(); err != nil {
+		return nil, err
+	}
+	zipFile.Close()
+
 	// 获取文件信息
 	fileInfo, err := os.Stat(backupPath)
 	if err != nil {
@@ -342,6 +453,14 @@ func (s *BackupService) CreateFullBackup() (*BackupInfo, error) {
 		return nil, err
 	}
 	backupInfo.MD5 = md5Hash
+	manifest := s.buildManifest(backupInfo)
+	if err := s.encryptBackup(backupInfo); err != nil {
+		return nil, err
+	}
+	if err := s.writeChecksum(backupInfo); err != nil {
+		return nil, err
+	}
+	s.saveManifest(backupInfo, manifest)
 
 	// 记录备份成功日志
 	s.storageManager.LogInfo("完整备份成功", map[string]interface{}{
@@ -351,6 +470,7 @@ func (s *BackupService) CreateFullBackup() (*BackupInfo, error) {
 		"md5":       backupInfo.MD5,
 	})
 
+	s.publishBackupEvent(backupInfo, nil)
 	return backupInfo, nil
 }
 
@@ -362,11 +482,29 @@ func (s *BackupService) CreateFullBackup() (*BackupInfo, error) {
 // 4. 提供恢复进度反馈
 // 5. 记录恢复日志
 func (s *BackupService) RestoreBackup(backupPath string, backupType string) error {
+	// 按表备份恢复其中的全部表，只影响备份中的表
+	if backupType == "tables" {
+		_, err := s.RestoreSelected(context.Background(), backupPath, BackupSelection{})
+		return err
+	}
+
 	// 验证备份文件
 	if err := s.validateBackup(backupPath); err != nil {
 		return fmt.Errorf("备份文件验证失败: %v", err)
 	}
 
+	// 加密备份先解密到临时目录，再按原备份恢复
+	if IsEncryptedBackup(backupPath) {
+		tempDir, err := os.MkdirTemp(s.backupPath, "decrypt_")
+		if err != nil {
+			return fmt.Errorf("创建临时目录失败: %v", err)
+		}
+		defer os.RemoveAll(tempDir)
+		if backupPath, err = s.decryptBackupInto(context.Background(), backupPath, tempDir); err != nil {
+			return fmt.Errorf("备份解密失败: %v", err)
+		}
+	}
+
 	switch backupType {
 	case "database":
 		return s.restoreDatabase(backupPath)
@@ -389,7 +527,7 @@ func (s *BackupService) ListBackups() ([]*BackupInfo, error) {
 	}
 
 	for _, file := range files {
-		if file.IsDir() {
+		if file.IsDir() || strings.HasSuffix(file.Name(), backupChecksumExt) || strings.HasSuffix(file.Name(), backupManifestExt) {
 			continue
 		}
 
@@ -414,6 +552,13 @@ func (s *BackupService) ListBackups() ([]*BackupInfo, error) {
 		if err == nil {
 			backupInfo.MD5 = md5Hash
 		}
+		backupInfo.SHA256, _ = readChecksum(filePath)
+		backupInfo.Manifest, _ = s.ReadBackupManifest(filePath)
+		if IsEncryptedBackup(filePath) {
+			if header, err := ReadBackupEncryptionHeader(filePath); err == nil {
+				backupInfo.Metadata["encryption"] = header.Summary()
+			}
+		}
 
 		backups = append(backups, backupInfo)
 	}
@@ -421,6 +566,30 @@ func (s *BackupService) ListBackups() ([]*BackupInfo, error) {
 	return backups, nil
 }
 
+// FindBackup 按备份ID或文件名查找备份
+func (s *BackupService) FindBackup(id string) (*BackupInfo, error) {
+	backups, err := s.ListBackups()
+	if err != nil {
+		return nil, err
+	}
+	for _, backup := range backups {
+		if backup.ID == id || filepath.Base(backup.Path) == id || strings.SplitN(filepath.Base(backup.Path), ".", 2)[0] == id {
+			return backup, nil
+		}
+	}
+	return nil, ErrBackupNotFound
+}
+
+// DeleteBackup 删除备份文件及其校验和、清单文件
+func (s *BackupService) DeleteBackup(backupPath string) error {
+	if err := os.Remove(backupPath); err != nil {
+		return err
+	}
+	os.Remove(backupPath + backupChecksumExt)
+	os.Remove(backupPath + backupManifestExt)
+	return nil
+}
+
 // CleanupOldBackups 清理旧备份
 func (s *BackupService) CleanupOldBackups() error {
 	backups, err := s.ListBackups()
@@ -433,7 +602,7 @@ func (s *BackupService) CleanupOldBackups() error {
 
 	for _, backup := range backups {
 		if backup.CreatedAt.Before(cutoffTime) {
-			if err := os.Remove(backup.Path); err != nil {
+			if err := s.DeleteBackup(backup.Path); err != nil {
 				s.storageManager.LogError("删除旧备份失败", map[string]interface{}{
 					"backup_id": backup.ID,
 					"path":      backup.Path,
@@ -460,7 +629,7 @@ func (s *BackupService) CleanupOldBackups() error {
 // 私有方法
 
 func (s *BackupService) backupMySQL(backupPath string) error {
-	dbConfig := Config.GetConfig().Database
+	dbConfig := s.databaseConfig()
 
 	// 构建mysqldump命令
 	cmd := exec.Command("mysqldump",
@@ -509,7 +678,7 @@ func (s *BackupService) backupMySQL(backupPath string) error {
 }
 
 func (s *BackupService) backupPostgreSQL(backupPath string) error {
-	dbConfig := Config.GetConfig().Database
+	dbConfig := s.databaseConfig()
 
 	// 构建pg_dump命令
 	cmd := exec.Command("pg_dump",
@@ -554,7 +723,7 @@ func (s *BackupService) backupPostgreSQL(backupPath string) error {
 }
 
 func (s *BackupService) backupSQLite(backupPath string) error {
-	dbConfig := Config.GetConfig().Database
+	dbConfig := s.databaseConfig()
 
 	// 对于SQLite，直接复制数据库文件
 	sourceFile, err := os.Open(dbConfig.Database)
@@ -574,7 +743,7 @@ func (s *BackupService) backupSQLite(backupPath string) error {
 }
 
 func (s *BackupService) backupDatabaseToFile(backupPath string) error {
-	dbConfig := Config.GetConfig().Database
+	dbConfig := s.databaseConfig()
 
 	switch dbConfig.Driver {
 	case "mysql":
@@ -706,11 +875,18 @@ func (s *BackupService) validateBackup(backupPath string) error {
 		return fmt.Errorf("备份文件为空")
 	}
 
+	// 有校验和文件时校验内容，早于校验和功能的备份只检查大小
+	if _, err := readChecksum(backupPath); err == nil {
+		if _, err := s.VerifyBackup(backupPath); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 func (s *BackupService) restoreDatabase(backupPath string) error {
-	dbConfig := Config.GetConfig().Database
+	dbConfig := s.databaseConfig()
 
 	// 检查备份文件是否存在
 	if _, err := os.Stat(backupPath); err != nil {
@@ -720,9 +896,9 @@ func (s *BackupService) restoreDatabase(backupPath string) error {
 	// 根据数据库类型执行恢复
 	switch dbConfig.Driver {
 	case "mysql":
-		return s.restoreMySQL(backupPath)
+		return s.restoreMySQL(backupPath, dbConfig)
 	case "postgres":
-		return s.restorePostgreSQL(backupPath)
+		return s.restorePostgreSQL(backupPath, dbConfig)
 	case "sqlite":
 		return s.restoreSQLite(backupPath)
 	default:
@@ -730,9 +906,8 @@ func (s *BackupService) restoreDatabase(backupPath string) error {
 	}
 }
 
-func (s *BackupService) restoreMySQL(backupPath string) error {
-	dbConfig := Config.GetConfig().Database
-
+// restoreMySQL 把SQL备份导入 dbConfig 指定的数据库，恢复演练时为临时数据库
+func (s *BackupService) restoreMySQL(backupPath string, dbConfig Config.DatabaseConfig) error {
 	// 构建mysql命令
 	cmd := exec.Command("mysql",
 		"-h", dbConfig.Host,
@@ -760,9 +935,8 @@ func (s *BackupService) restoreMySQL(backupPath string) error {
 	return nil
 }
 
-func (s *BackupService) restorePostgreSQL(backupPath string) error {
-	dbConfig := Config.GetConfig().Database
-
+// restorePostgreSQL 把SQL备份导入 dbConfig 指定的数据库，恢复演练时为临时数据库
+func (s *BackupService) restorePostgreSQL(backupPath string, dbConfig Config.DatabaseConfig) error {
 	// 构建psql命令
 	cmd := exec.Command("psql",
 		"-h", dbConfig.Host,
@@ -784,7 +958,7 @@ func (s *BackupService) restorePostgreSQL(backupPath string) error {
 }
 
 func (s *BackupService) restoreSQLite(backupPath string) error {
-	dbConfig := Config.GetConfig().Database
+	dbConfig := s.databaseConfig()
 
 	// 对于SQLite，直接复制备份文件到数据库位置
 	sourceFile, err := os.Open(backupPath)
@@ -940,8 +1114,8 @@ func (s *BackupService) parseBackupInfo(fileName string) (*BackupInfo, error) {
 	}
 
 	backupType := parts[0]
-	dateTime := parts[1] + "_" + parts[2]
-	dateTime = strings.TrimSuffix(dateTime, filepath.Ext(dateTime))
+	// 压缩后的备份有多个扩展名，如 .sql.gz
+	dateTime := parts[1] + "_" + strings.SplitN(parts[2], ".", 2)[0]
 
 	createdAt, err := time.Parse("20060102_150405", dateTime)
 	if err != nil {
@@ -979,7 +1153,9 @@ func (s *BackupService) startAutoBackup() {
 	for range ticker.C {
 		// 创建完整备份
 		backupInfo, err := s.CreateFullBackup()
+		s.notifyBackupResult(backupInfo, err)
 		if err != nil {
+			s.publishBackupEvent(nil, err)
 			s.storageManager.LogError("自动备份失败", map[string]interface{}{
 				"error": err.Error(),
 			})
@@ -995,3 +1171,56 @@ func (s *BackupService) startAutoBackup() {
 		s.CleanupOldBackups()
 	}
 }
+
+// publishBackupEvent 发布备份完成或失败的领域事件，事件总线未启用时忽略
+func (s *BackupService) publishBackupEvent(backupInfo *BackupInfo, backupErr error) {
+	eventType, aggregateID := EventBackupCompleted, ""
+	payload := map[string]interface{}{}
+	if backupInfo != nil {
+		aggregateID = backupInfo.ID
+		payload["backup_id"] = backupInfo.ID
+		payload["type"] = backupInfo.Type
+		payload["path"] = backupInfo.Path
+		payload["size"] = backupInfo.Size
+		payload["md5"] = backupInfo.MD5
+	}
+	if backupErr != nil {
+		eventType = EventBackupFailed
+		payload["error"] = backupErr.Error()
+	}
+	if err := PublishDomainEvent(nil, eventType, "backup", aggregateID, payload); err != nil {
+		s.storageManager.LogError("发布备份事件失败", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+}
+
+// notifyBackupResult 自动备份结束后通知管理员
+func (s *BackupService) notifyBackupResult(backupInfo *BackupInfo, err error) {
+	notifications := DefaultNotificationService()
+	if notifications == nil {
+		return
+	}
+
+	title := "自动备份完成"
+	content := ""
+	data := map[string]interface{}{"status": "success"}
+	if backupInfo != nil {
+		data["backup_id"] = backupInfo.ID
+		data["type"] = backupInfo.Type
+		data["size"] = backupInfo.Size
+		content = fmt.Sprintf("备份 %s 已完成，大小 %d 字节", backupInfo.ID, backupInfo.Size)
+	}
+	if err != nil {
+		title = "自动备份失败"
+		content = "备份失败: " + err.Error()
+		data["status"] = "failed"
+		data["error"] = err.Error()
+	}
+
+	if _, notifyErr := notifications.NotifyAdmins(Models.NotificationTypeBackup, title, content, data); notifyErr != nil {
+		s.storageManager.LogError("发送备份通知失败", map[string]interface{}{
+			"error": notifyErr.Error(),
+		})
+	}
+}
@@ -0,0 +1,593 @@
+package Services
+
+import (
+	"archive/zip"
+	"bufio"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// 恢复演练结果
+const (
+	RestoreDrillSuccess = "success"
+	RestoreDrillFailed  = "failed"
+)
+
+// backupChecksumExt 校验和文件的扩展名，内容与 sha256sum 的输出格式相同
+const backupChecksumExt = ".sha256"
+
+// restoreDrillFile 保存最近一次恢复演练结果的文件，重启后监控指标仍能反映上次的结果
+const restoreDrillFile = "restore_drill.json"
+
+var (
+	// ErrBackupChecksumMissing 备份没有校验和文件
+	ErrBackupChecksumMissing = errors.New("备份缺少校验和文件")
+	// ErrBackupChecksumMismatch 备份内容与校验和不一致
+	ErrBackupChecksumMismatch = errors.New("备份校验和不一致，文件可能已损坏或被修改")
+	// ErrNoRestorableBackup 没有可用于恢复演练的数据库备份
+	ErrNoRestorableBackup = errors.New("没有可用于恢复演练的数据库备份")
+)
+
+// drillTableName 冒烟查询的表名只允许字母、数字和下划线
+var drillTableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// BackupVerification 备份校验结果
+type BackupVerification struct {
+	Path       string    `json:"path"`
+	SHA256     string    `json:"sha256"`      // 当前文件的SHA-256
+	Expected   string    `json:"expected"`    // 校验和文件中记录的SHA-256
+	ChecksumOK bool      `json:"checksum_ok"` // 校验和一致
+	ArchiveOK  bool      `json:"archive_ok"`  // 压缩包可以完整读取
+	VerifiedAt time.Time `json:"verified_at"`
+}
+
+// RestoreDrillResult 恢复演练结果
+type RestoreDrillResult struct {
+	BackupID   string           `json:"backup_id"`
+	BackupPath string           `json:"backup_path"`
+	Status     string           `json:"status"`
+	Checksum   bool             `json:"checksum"`    // 校验和通过
+	Tables     map[string]int64 `json:"tables"`      // 冒烟查询的表和行数
+	DurationMS int64            `json:"duration_ms"` // 演练耗时
+	Error      string           `json:"error,omitempty"`
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt time.Time        `json:"finished_at"`
+}
+
+// VerifyBackup 校验备份文件
+// 功能说明：
+// 1. 重新计算SHA-256并与备份时写入的 .sha256 文件比对
+// 2. gzip和zip备份完整读取一遍，由压缩格式自带的CRC发现截断和损坏
+// 3. 加密备份用主密钥解密全部分块，由GCM认证发现篡改
+func (s *BackupService) VerifyBackup(backupPath string) (*BackupVerification, error) {
+	verification := &BackupVerification{Path: backupPath, VerifiedAt: time.Now()}
+	expected, err := readChecksum(backupPath)
+	if err != nil {
+		return verification, err
+	}
+	verification.Expected = expected
+
+	actual, err := sha256File(backupPath)
+	if err != nil {
+		return verification, err
+	}
+	verification.SHA256 = actual
+	if actual != expected {
+		return verification, ErrBackupChecksumMismatch
+	}
+	verification.ChecksumOK = true
+
+	verify := verifyArchive
+	if IsEncryptedBackup(backupPath) {
+		verify = s.verifyEncryptedBackup
+	}
+	if err := verify(backupPath); err != nil {
+		return verification, fmt.Errorf("备份文件无法完整读取: %w", err)
+	}
+	verification.ArchiveOK = true
+	return verification, nil
+}
+
+// RunRestoreDrill 运行恢复演练
+// 功能说明：
+// 1. 取最新的数据库备份或完整备份，先校验校验和
+// 2. 恢复到临时数据库：SQLite为临时文件，MySQL和PostgreSQL为临时创建的数据库，演练结束后删除
+// 3. 对配置的表执行冒烟查询，表不存在或无法查询时演练失败
+// 4. 结果作为监控指标（backup_restore_drill_success 等）输出，失败时通知管理员并发布 backup.drill_failed 事件
+func (s *BackupService) RunRestoreDrill(ctx context.Context) (*RestoreDrillResult, error) {
+	result := &RestoreDrillResult{Status: RestoreDrillFailed, Tables: make(map[string]int64), StartedAt: time.Now()}
+	err := s.restoreDrill(ctx, result)
+	result.FinishedAt = time.Now()
+	result.DurationMS = result.FinishedAt.Sub(result.StartedAt).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		result.Status = RestoreDrillSuccess
+	}
+	s.recordDrill(result)
+	return result, err
+}
+
+// LastRestoreDrill 最近一次恢复演练的结果，没有运行过时为 nil
+func (s *BackupService) LastRestoreDrill() *RestoreDrillResult {
+	s.drillMu.RLock()
+	defer s.drillMu.RUnlock()
+	return s.lastDrill
+}
+
+// Collector 恢复演练的监控指标采集器
+// backup_restore_drill_success 为最近一次演练是否成功（1/0），从未运行时不输出
+func (s *BackupService) Collector() MetricCollector {
+	return NewMetricCollector("backup", func(ctx context.Context) (map[string]float64, error) {
+		values := make(map[string]float64)
+		drill := s.LastRestoreDrill()
+		if drill == nil {
+			return values, nil
+		}
+		values["backup_restore_drill_success"] = 0
+		if drill.Status == RestoreDrillSuccess {
+			values["backup_restore_drill_success"] = 1
+		}
+		values["backup_restore_drill_duration_seconds"] = float64(drill.DurationMS) / 1000
+		values["backup_restore_drill_last_run_timestamp"] = float64(drill.FinishedAt.Unix())
+		values["backup_restore_drill_age_seconds"] = time.Since(drill.FinishedAt).Seconds()
+		return values, nil
+	})
+}
+
+// AlertRules 恢复演练的告警规则：最近一次演练失败，或超过两个演练间隔没有运行
+func (s *BackupService) AlertRules() []*AlertRule {
+	rules := []*AlertRule{{
+		ID:        "backup_restore_drill_failed",
+		Name:      "备份恢复演练失败",
+		Metric:    "backup_restore_drill_success",
+		Condition: "<",
+		Threshold: 1,
+		Level:     AlertLevelCritical,
+		Enabled:   true,
+	}}
+	if s.config.RestoreDrillInterval > 0 {
+		rules = append(rules, &AlertRule{
+			ID:        "backup_restore_drill_stale",
+			Name:      "备份恢复演练长时间未运行",
+			Metric:    "backup_restore_drill_age_seconds",
+			Condition: ">",
+			Threshold: (2 * s.config.RestoreDrillInterval).Seconds(),
+			Level:     AlertLevelWarning,
+			Enabled:   true,
+		})
+	}
+	return rules
+}
+
+// restoreDrill 执行恢复演练，结果写入 result
+func (s *BackupService) restoreDrill(ctx context.Context, result *RestoreDrillResult) error {
+	backup, err := s.latestDatabaseBackup()
+	if err != nil {
+		return err
+	}
+	result.BackupID = backup.ID
+	result.BackupPath = backup.Path
+
+	if _, err := s.VerifyBackup(backup.Path); err != nil {
+		return err
+	}
+	result.Checksum = true
+
+	tempDir, err := os.MkdirTemp(s.backupPath, "restore_drill_")
+	if err != nil {
+		return fmt.Errorf("创建临时目录失败: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	sourcePath := backup.Path
+	if IsEncryptedBackup(sourcePath) {
+		if sourcePath, err = s.decryptBackupInto(ctx, sourcePath, tempDir); err != nil {
+			return err
+		}
+	}
+	dumpPath, err := extractDatabaseDump(sourcePath, tempDir)
+	if err != nil {
+		return err
+	}
+	db, cleanup, err := s.openDrillDatabase(dumpPath, tempDir)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	return s.runSmokeQueries(ctx, db, result)
+}
+
+// latestDatabaseBackup 最新的数据库备份或完整备份
+func (s *BackupService) latestDatabaseBackup() (*BackupInfo, error) {
+	backups, err := s.ListBackups()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	for _, backup := range backups {
+		if backup.Type == "db" || backup.Type == "full" {
+			return backup, nil
+		}
+	}
+	return nil, ErrNoRestorableBackup
+}
+
+// openDrillDatabase 把备份恢复到临时数据库并打开连接，返回的 cleanup 关闭连接并删除临时数据库
+func (s *BackupService) openDrillDatabase(dumpPath, tempDir string) (*gorm.DB, func(), error) {
+	dbConfig := s.databaseConfig()
+	gormConfig := &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)}
+
+	switch dbConfig.Driver {
+	case "sqlite":
+		// SQLite备份即数据库文件，解压得到的副本直接作为临时数据库
+		db, err := gorm.Open(sqlite.Open(dumpPath), gormConfig)
+		if err != nil {
+			return nil, nil, fmt.Errorf("打开恢复的数据库失败: %v", err)
+		}
+		return db, func() { closeGormDB(db) }, nil
+	case "mysql", "postgres":
+	default:
+		return nil, nil, fmt.Errorf("不支持的数据库驱动: %s", dbConfig.Driver)
+	}
+
+	admin, err := gorm.Open(drillDialector(dbConfig), gormConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("连接数据库失败: %v", err)
+	}
+	drillConfig := dbConfig
+	drillConfig.Database = drillDatabaseName(dbConfig.Database)
+	quoted := admin.Statement.Quote(drillConfig.Database)
+	if err := admin.Exec("CREATE DATABASE " + quoted).Error; err != nil {
+		closeGormDB(admin)
+		return nil, nil, fmt.Errorf("创建临时数据库失败: %v", err)
+	}
+	dropDatabase := func() {
+		if err := admin.Exec("DROP DATABASE IF EXISTS " + quoted).Error; err != nil {
+			s.storageManager.LogError("删除恢复演练临时数据库失败", map[string]interface{}{
+				"database": drillConfig.Database,
+				"error":    err.Error(),
+			})
+		}
+		closeGormDB(admin)
+	}
+
+	if dbConfig.Driver == "mysql" {
+		err = s.restoreMySQL(dumpPath, drillConfig)
+	} else {
+		// pg_dump 使用了 --create，去掉建库和切换数据库的语句，避免导入到原数据库
+		var filtered string
+		if filtered, err = filterPostgresDump(dumpPath, tempDir); err == nil {
+			err = s.restorePostgreSQL(filtered, drillConfig)
+		}
+	}
+	if err != nil {
+		dropDatabase()
+		return nil, nil, err
+	}
+
+	db, err := gorm.Open(drillDialector(drillConfig), gormConfig)
+	if err != nil {
+		dropDatabase()
+		return nil, nil, fmt.Errorf("连接临时数据库失败: %v", err)
+	}
+	return db, func() {
+		closeGormDB(db)
+		dropDatabase()
+	}, nil
+}
+
+// runSmokeQueries 在恢复的数据库上执行冒烟查询，记录每个表的行数
+func (s *BackupService) runSmokeQueries(ctx context.Context, db *gorm.DB, result *RestoreDrillResult) error {
+	db = db.WithContext(ctx)
+	if s.databaseConfig().Driver == "sqlite" {
+		var check string
+		if err := db.Raw("PRAGMA integrity_check").Scan(&check).Error; err != nil {
+			return fmt.Errorf("完整性检查失败: %v", err)
+		}
+		if check != "ok" {
+			return fmt.Errorf("完整性检查失败: %s", check)
+		}
+	} else if err := db.Exec("SELECT 1").Error; err != nil {
+		return fmt.Errorf("冒烟查询失败: %v", err)
+	}
+
+	for _, table := range s.config.RestoreDrillTables {
+		table = strings.TrimSpace(table)
+		if table == "" {
+			continue
+		}
+		if !drillTableName.MatchString(table) {
+			return fmt.Errorf("冒烟查询的表名无效: %s", table)
+		}
+		if !db.Migrator().HasTable(table) {
+			return fmt.Errorf("冒烟查询失败: 恢复的数据库中没有表 %s", table)
+		}
+		var count int64
+		if err := db.Table(table).Count(&count).Error; err != nil {
+			return fmt.Errorf("冒烟查询失败: 表 %s: %v", table, err)
+		}
+		result.Tables[table] = count
+	}
+	return nil
+}
+
+// recordDrill 保存演练结果，记录日志，失败时通知管理员并发布事件
+func (s *BackupService) recordDrill(result *RestoreDrillResult) {
+	s.drillMu.Lock()
+	s.lastDrill = result
+	s.drillMu.Unlock()
+
+	if data, err := json.MarshalIndent(result, "", "  "); err == nil {
+		if err := os.WriteFile(filepath.Join(s.backupPath, restoreDrillFile), data, 0644); err != nil {
+			s.storageManager.LogWarning("保存恢复演练结果失败", map[string]interface{}{"error": err.Error()})
+		}
+	}
+
+	fields := map[string]interface{}{
+		"backup_id":   result.BackupID,
+		"status":      result.Status,
+		"duration_ms": result.DurationMS,
+		"tables":      result.Tables,
+	}
+	if result.Status == RestoreDrillSuccess {
+		s.storageManager.LogInfo("备份恢复演练成功", fields)
+		return
+	}
+	fields["error"] = result.Error
+	s.storageManager.LogError("备份恢复演练失败", fields)
+
+	payload := map[string]interface{}{"backup_id": result.BackupID, "path": result.BackupPath, "error": result.Error}
+	if err := PublishDomainEvent(nil, EventBackupDrillFailed, "backup", result.BackupID, payload); err != nil {
+		s.storageManager.LogError("发布备份事件失败", map[string]interface{}{"error": err.Error()})
+	}
+	if notifications := DefaultNotificationService(); notifications != nil {
+		content := fmt.Sprintf("备份 %s 恢复演练失败: %s", result.BackupID, result.Error)
+		if result.BackupID == "" {
+			content = "恢复演练失败: " + result.Error
+		}
+		if _, err := notifications.NotifyAdmins(Models.NotificationTypeBackup, "备份恢复演练失败", content, payload); err != nil {
+			s.storageManager.LogError("发送备份通知失败", map[string]interface{}{"error": err.Error()})
+		}
+	}
+}
+
+// loadLastDrill 加载上次保存的演练结果
+func (s *BackupService) loadLastDrill() {
+	data, err := os.ReadFile(filepath.Join(s.backupPath, restoreDrillFile))
+	if err != nil {
+		return
+	}
+	var result RestoreDrillResult
+	if json.Unmarshal(data, &result) == nil && !result.FinishedAt.IsZero() {
+		s.lastDrill = &result
+	}
+}
+
+// startRestoreDrills 按演练间隔运行恢复演练
+func (s *BackupService) startRestoreDrills() {
+	ticker := time.NewTicker(s.config.RestoreDrillInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.RunRestoreDrill(context.Background())
+	}
+}
+
+// writeChecksum 计算备份文件的SHA-256并写入同名的 .sha256 文件
+func (s *BackupService) writeChecksum(backupInfo *BackupInfo) error {
+	sum, err := sha256File(backupInfo.Path)
+	if err != nil {
+		return err
+	}
+	line := fmt.Sprintf("%s  %s\n", sum, filepath.Base(backupInfo.Path))
+	if err := os.WriteFile(backupInfo.Path+backupChecksumExt, []byte(line), 0644); err != nil {
+		return fmt.Errorf("写入校验和文件失败: %v", err)
+	}
+	backupInfo.SHA256 = sum
+	return nil
+}
+
+// readChecksum 读取备份的校验和文件
+func readChecksum(backupPath string) (string, error) {
+	data, err := os.ReadFile(backupPath + backupChecksumExt)
+	if os.IsNotExist(err) {
+		return "", ErrBackupChecksumMissing
+	}
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 || len(fields[0]) != sha256.Size*2 {
+		return "", fmt.Errorf("校验和文件格式错误: %s", backupPath+backupChecksumExt)
+	}
+	return strings.ToLower(fields[0]), nil
+}
+
+// sha256File 计算文件的SHA-256
+func sha256File(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// verifyArchive 完整读取gzip和zip备份，其他格式不检查
+func verifyArchive(path string) error {
+	if strings.HasSuffix(path, ".zip") {
+		archive, err := zip.OpenReader(path)
+		if err != nil {
+			return err
+		}
+		defer archive.Close()
+		for _, file := range archive.File {
+			entry, err := file.Open()
+			if err != nil {
+				return fmt.Errorf("%s: %v", file.Name, err)
+			}
+			_, err = io.Copy(io.Discard, entry)
+			entry.Close()
+			if err != nil {
+				return fmt.Errorf("%s: %v", file.Name, err)
+			}
+		}
+		return nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return verifyArchiveStream(path, file)
+}
+
+// verifyArchiveStream 按文件名完整读取gzip数据流；zip需要随机访问，流中只能完整读取
+func verifyArchiveStream(name string, r io.Reader) error {
+	if !strings.HasSuffix(name, ".gz") {
+		_, err := io.Copy(io.Discard, r)
+		return err
+	}
+	reader, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	_, err = io.Copy(io.Discard, reader)
+	return err
+}
+
+// extractDatabaseDump 从备份中取出数据库备份文件，写入临时目录
+// 数据库备份可能经过gzip压缩；完整备份为zip，数据库在其中的 database.sql
+func extractDatabaseDump(backupPath, tempDir string) (string, error) {
+	var source io.ReadCloser
+	switch {
+	case strings.HasSuffix(backupPath, ".zip"):
+		archive, err := zip.OpenReader(backupPath)
+		if err != nil {
+			return "", fmt.Errorf("打开备份文件失败: %v", err)
+		}
+		defer archive.Close()
+		entry, err := archive.Open("database.sql")
+		if err != nil {
+			return "", fmt.Errorf("完整备份中没有数据库备份: %v", err)
+		}
+		source = entry
+	case strings.HasSuffix(backupPath, ".gz"):
+		file, err := os.Open(backupPath)
+		if err != nil {
+			return "", fmt.Errorf("打开备份文件失败: %v", err)
+		}
+		defer file.Close()
+		reader, err := gzip.NewReader(file)
+		if err != nil {
+			return "", fmt.Errorf("解压备份文件失败: %v", err)
+		}
+		source = reader
+	default:
+		file, err := os.Open(backupPath)
+		if err != nil {
+			return "", fmt.Errorf("打开备份文件失败: %v", err)
+		}
+		source = file
+	}
+	defer source.Close()
+
+	dumpPath := filepath.Join(tempDir, "database.sql")
+	target, err := os.Create(dumpPath)
+	if err != nil {
+		return "", err
+	}
+	defer target.Close()
+	if _, err := io.Copy(target, source); err != nil {
+		return "", fmt.Errorf("读取数据库备份失败: %v", err)
+	}
+	return dumpPath, target.Close()
+}
+
+// filterPostgresDump 去掉 pg_dump --create --clean 生成的建库、删库和 \connect 语句
+func filterPostgresDump(dumpPath, tempDir string) (string, error) {
+	source, err := os.Open(dumpPath)
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+
+	filteredPath := filepath.Join(tempDir, "database.filtered.sql")
+	target, err := os.Create(filteredPath)
+	if err != nil {
+		return "", err
+	}
+	defer target.Close()
+
+	writer := bufio.NewWriter(target)
+	scanner := bufio.NewScanner(source)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		upper := strings.ToUpper(strings.TrimSpace(line))
+		if strings.HasPrefix(upper, "CREATE DATABASE") || strings.HasPrefix(upper, "DROP DATABASE") ||
+			strings.HasPrefix(upper, "ALTER DATABASE") || strings.HasPrefix(upper, `\CONNECT`) {
+			continue
+		}
+		writer.WriteString(line)
+		writer.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if err := writer.Flush(); err != nil {
+		return "", err
+	}
+	return filteredPath, target.Close()
+}
+
+// drillDatabaseName 恢复演练临时数据库的名称
+func drillDatabaseName(database string) string {
+	name := regexp.MustCompile(`[^A-Za-z0-9_]`).ReplaceAllString(database, "_")
+	return fmt.Sprintf("%s_restore_drill_%d", name, time.Now().Unix())
+}
+
+// drillDialector 按数据库配置创建连接
+func drillDialector(dbConfig Config.DatabaseConfig) gorm.Dialector {
+	if dbConfig.Driver == "mysql" {
+		return mysql.Open(dbConfig.GetDSN())
+	}
+	return postgres.Open(dbConfig.GetDSN())
+}
+
+// closeGormDB 关闭连接
+func closeGormDB(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
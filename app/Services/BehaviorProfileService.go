@@ -0,0 +1,541 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 行为偏离类型
+const (
+	BehaviorDeviationLoginHour   = "unusual_login_hour"
+	BehaviorDeviationNewIP       = "new_ip"
+	BehaviorDeviationNewNetwork  = "new_network"
+	BehaviorDeviationNewEndpoint = "new_endpoint"
+	BehaviorDeviationRequestRate = "request_rate"
+)
+
+// BehaviorEventLogin 登录事件类型，只有登录事件计入登录时段分布
+const BehaviorEventLogin = "login"
+
+// 行为画像参数
+const (
+	behaviorMaxEntries     = 100 // IP、网段、接口各保留的最大条数
+	behaviorMinEvents      = 50  // 判断IP、接口偏离前至少需要的事件数
+	behaviorMinLogins      = 10  // 判断登录时段偏离前至少需要的登录次数
+	behaviorMinRateSamples = 10  // 判断请求频率偏离前至少需要的活跃分钟数
+	behaviorRateZThreshold = 3.0 // 每分钟请求数超过均值的标准差倍数
+)
+
+// 各类偏离的基础分数
+var behaviorDeviationScores = map[string]float64{
+	BehaviorDeviationLoginHour:   0.4,
+	BehaviorDeviationNewIP:       0.3,
+	BehaviorDeviationNewNetwork:  0.5,
+	BehaviorDeviationNewEndpoint: 0.2,
+}
+
+// 路径中的数字ID和UUID，统计接口时替换为 :id
+var behaviorPathIDPattern = regexp.MustCompile(`/(\d+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})(/|$)`)
+
+// BehaviorDeviation 偏离用户行为基线的说明
+type BehaviorDeviation struct {
+	Type     string  `json:"type"`
+	Message  string  `json:"message"`
+	Observed string  `json:"observed"`
+	Baseline string  `json:"baseline,omitempty"`
+	Score    float64 `json:"score"`
+}
+
+// NetworkResolver 将IP地址解析为网段标识（如ASN），无法解析时返回空字符串
+type NetworkResolver func(ipAddress string) string
+
+// 行为画像缓存参数
+const (
+	behaviorMaxProfiles    = 10000            // 内存中最多缓存的用户画像数
+	behaviorProfileIdleTTL = 30 * time.Minute // 超过该时间未访问的画像从内存淘汰
+	behaviorSaveInterval   = 30 * time.Second // 同一用户画像两次写入数据库的最小间隔
+)
+
+// BehaviorProfileService 用户行为画像服务
+// 功能说明：
+// 1. 按用户维护登录时段、常用IP/网段、常用接口和每分钟请求数分布，每次事件增量更新
+// 2. 事件先与更新前的画像比较，给出偏离基线的说明和0-1的偏离分数
+// 3. 学习模式下，用户首次出现后的 LearningPeriod 内只更新画像不判断偏离
+// 4. 网段默认按IPv4 /24、IPv6 /48 前缀归类，可通过 SetNetworkResolver 接入ASN查询
+// 5. 画像按用户加锁，在锁外写入数据库，同一用户两次写入至少间隔 behaviorSaveInterval
+// 6. 内存中的画像按最近访问淘汰，数量超过上限或空闲超过 behaviorProfileIdleTTL 时淘汰，淘汰前写入未保存的更新
+//
+// 注意事项：
+// - 最近一次写入后的更新在下一次写入、淘汰或调用 Flush 之前只保存在内存中
+type BehaviorProfileService struct {
+	db             *gorm.DB
+	learningPeriod time.Duration
+	resolver       NetworkResolver
+	maxProfiles    int
+	idleTTL        time.Duration
+	saveInterval   time.Duration
+	profiles       map[uint]*behaviorProfileEntry
+	recent         *list.List // 按最近访问排序的用户ID，最前面是最近访问的
+	mu             sync.Mutex // 保护 resolver、缓存参数、profiles 和 recent
+}
+
+// behaviorProfileEntry 内存中的用户画像
+// lastUsed 和 element 由服务的 mu 保护，evicted 为原子变量，其余字段由条目的 mu 保护
+// 不能在持有服务锁时等待条目锁，避免加载画像时阻塞其他用户
+type behaviorProfileEntry struct {
+	mu       sync.Mutex
+	profile  *Models.UserBehaviorProfile
+	dirty    bool        // 有未写入数据库的更新
+	saving   bool        // 正在写入数据库
+	evicted  atomic.Bool // 已从内存淘汰，之后的更新立即写入
+	savedAt  time.Time
+	lastUsed time.Time
+	element  *list.Element
+}
+
+// NewBehaviorProfileService 创建用户行为画像服务
+func NewBehaviorProfileService(db *gorm.DB, config *Config.AnomalyDetectionConfig) *BehaviorProfileService {
+	service := &BehaviorProfileService{
+		db:           db,
+		resolver:     ipPrefixNetwork,
+		maxProfiles:  behaviorMaxProfiles,
+		idleTTL:      behaviorProfileIdleTTL,
+		saveInterval: behaviorSaveInterval,
+		profiles:     make(map[uint]*behaviorProfileEntry),
+		recent:       list.New(),
+	}
+	if config != nil && config.LearningMode {
+		service.learningPeriod = config.LearningPeriod
+	}
+	return service
+}
+
+// SetNetworkResolver 设置网段解析函数
+func (s *BehaviorProfileService) SetNetworkResolver(resolver NetworkResolver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if resolver == nil {
+		resolver = ipPrefixNetwork
+	}
+	s.resolver = resolver
+}
+
+// SetCacheLimits 设置内存中最多缓存的画像数和空闲淘汰时间，小于等于0的参数保持不变
+func (s *BehaviorProfileService) SetCacheLimits(maxProfiles int, idleTTL time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if maxProfiles > 0 {
+		s.maxProfiles = maxProfiles
+	}
+	if idleTTL > 0 {
+		s.idleTTL = idleTTL
+	}
+}
+
+// SetSaveInterval 设置同一用户画像两次写入数据库的最小间隔，0表示每次事件都写入
+func (s *BehaviorProfileService) SetSaveInterval(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if interval >= 0 {
+		s.saveInterval = interval
+	}
+}
+
+// CachedProfiles 内存中缓存的画像数
+func (s *BehaviorProfileService) CachedProfiles() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.profiles)
+}
+
+// Evaluate 比较事件与用户画像并更新画像，返回偏离说明和合并后的偏离分数
+func (s *BehaviorProfileService) Evaluate(ctx context.Context, observation AnomalyObservation) ([]BehaviorDeviation, float64, error) {
+	s.mu.Lock()
+	resolver, saveInterval := s.resolver, s.saveInterval
+	s.mu.Unlock()
+
+	entry, err := s.entry(ctx, observation.UserID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	network := resolver(observation.IPAddress)
+	endpoint := behaviorEndpoint(observation.Action, observation.Resource)
+
+	entry.mu.Lock()
+	profile := entry.profile
+	var deviations []BehaviorDeviation
+	if observation.Time.Sub(profile.FirstSeenAt) >= s.learningPeriod {
+		deviations = behaviorDeviations(profile, observation, network, endpoint)
+	}
+	updateBehaviorProfile(profile, observation, network, endpoint)
+	entry.dirty = true
+	var snapshot *Models.UserBehaviorProfile
+	if !entry.saving && (entry.evicted.Load() || time.Since(entry.savedAt) >= saveInterval) {
+		snapshot = entry.takeSnapshot()
+	}
+	entry.mu.Unlock()
+
+	score := combineDeviationScores(deviations)
+	if snapshot != nil {
+		if err := s.save(ctx, entry, snapshot); err != nil {
+			return deviations, score, err
+		}
+	}
+	return deviations, score, nil
+}
+
+// GetProfile 获取用户行为画像
+func (s *BehaviorProfileService) GetProfile(ctx context.Context, userID uint) (*Models.UserBehaviorProfile, error) {
+	entry, err := s.entry(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	return copyBehaviorProfile(entry.profile), nil
+}
+
+// Flush 将内存中未保存的画像全部写入数据库，返回第一个写入错误
+func (s *BehaviorProfileService) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	entries := make([]*behaviorProfileEntry, 0, len(s.profiles))
+	for _, entry := range s.profiles {
+		entries = append(entries, entry)
+	}
+	s.mu.Unlock()
+
+	var firstErr error
+	for _, entry := range entries {
+		if err := s.flushEntry(ctx, entry); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// entry 获取用户的画像条目，不在内存中时从数据库加载，不存在时创建新画像
+// 只有加载同一用户画像的请求互相等待，数据库查询不持有服务锁
+func (s *BehaviorProfileService) entry(ctx context.Context, userID uint) (*behaviorProfileEntry, error) {
+	s.mu.Lock()
+	now := time.Now()
+	entry, ok := s.profiles[userID]
+	if ok {
+		s.recent.MoveToFront(entry.element)
+	} else {
+		entry = &behaviorProfileEntry{}
+		entry.element = s.recent.PushFront(userID)
+		s.profiles[userID] = entry
+	}
+	entry.lastUsed = now
+	evicted := s.evictLocked(now)
+	s.mu.Unlock()
+
+	for _, old := range evicted {
+		if err := s.flushEntry(ctx, old); err != nil {
+			log.Printf("淘汰用户行为画像前保存失败: %v", err)
+		}
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.profile != nil {
+		return entry, nil
+	}
+
+	profile := &Models.UserBehaviorProfile{}
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).First(profile).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		profile = &Models.UserBehaviorProfile{UserID: userID}
+	} else if err != nil {
+		s.mu.Lock()
+		if s.profiles[userID] == entry {
+			s.recent.Remove(entry.element)
+			delete(s.profiles, userID)
+		}
+		s.mu.Unlock()
+		return nil, fmt.Errorf("加载用户行为画像失败: %v", err)
+	}
+	if profile.IPAddresses == nil {
+		profile.IPAddresses = make(map[string]int64)
+	}
+	if profile.Networks == nil {
+		profile.Networks = make(map[string]int64)
+	}
+	if profile.Endpoints == nil {
+		profile.Endpoints = make(map[string]int64)
+	}
+	entry.profile = profile
+	return entry, nil
+}
+
+// evictLocked 从内存中移除超过数量上限和空闲超时的画像，调用方需持有服务锁
+func (s *BehaviorProfileService) evictLocked(now time.Time) []*behaviorProfileEntry {
+	var evicted []*behaviorProfileEntry
+	for element := s.recent.Back(); element != nil; element = s.recent.Back() {
+		userID := element.Value.(uint)
+		entry := s.profiles[userID]
+		if s.recent.Len() <= s.maxProfiles && now.Sub(entry.lastUsed) < s.idleTTL {
+			break
+		}
+		s.recent.Remove(element)
+		delete(s.profiles, userID)
+		entry.evicted.Store(true)
+		evicted = append(evicted, entry)
+	}
+	return evicted
+}
+
+// flushEntry 写入条目未保存的更新
+func (s *BehaviorProfileService) flushEntry(ctx context.Context, entry *behaviorProfileEntry) error {
+	entry.mu.Lock()
+	var snapshot *Models.UserBehaviorProfile
+	if entry.profile != nil && entry.dirty && !entry.saving {
+		snapshot = entry.takeSnapshot()
+	}
+	entry.mu.Unlock()
+
+	if snapshot == nil {
+		return nil
+	}
+	return s.save(ctx, entry, snapshot)
+}
+
+// save 在锁外写入画像快照，写入失败时条目重新标记为未保存
+func (s *BehaviorProfileService) save(ctx context.Context, entry *behaviorProfileEntry, snapshot *Models.UserBehaviorProfile) error {
+	err := s.db.WithContext(ctx).Save(snapshot).Error
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	entry.saving = false
+	if err != nil {
+		entry.dirty = true
+		return fmt.Errorf("保存用户行为画像失败: %v", err)
+	}
+	entry.savedAt = time.Now()
+	// 新画像首次写入后记录主键，之后的写入更新同一条记录
+	if entry.profile.ID == 0 {
+		entry.profile.ID = snapshot.ID
+		entry.profile.CreatedAt = snapshot.CreatedAt
+	}
+	return nil
+}
+
+// takeSnapshot 复制待写入的画像并标记为正在写入，调用方需持有条目锁
+func (e *behaviorProfileEntry) takeSnapshot() *Models.UserBehaviorProfile {
+	e.dirty = false
+	e.saving = true
+	return copyBehaviorProfile(e.profile)
+}
+
+// copyBehaviorProfile 深拷贝画像，写入数据库和返回给调用方时不与内存中的画像共享计数表
+func copyBehaviorProfile(profile *Models.UserBehaviorProfile) *Models.UserBehaviorProfile {
+	copied := *profile
+	copied.IPAddresses = copyCounts(profile.IPAddresses)
+	copied.Networks = copyCounts(profile.Networks)
+	copied.Endpoints = copyCounts(profile.Endpoints)
+	return &copied
+}
+
+func copyCounts(counts map[string]int64) map[string]int64 {
+	copied := make(map[string]int64, len(counts))
+	for key, value := range counts {
+		copied[key] = value
+	}
+	return copied
+}
+
+// behaviorDeviations 比较事件与画像
+func behaviorDeviations(profile *Models.UserBehaviorProfile, observation AnomalyObservation, network, endpoint string) []BehaviorDeviation {
+	var deviations []BehaviorDeviation
+
+	if observation.EventType == BehaviorEventLogin && profile.LoginCount >= behaviorMinLogins {
+		// 相邻小时也算常用时段，避免边界附近的正常登录被误判
+		hour := observation.Time.Hour()
+		nearby := profile.LoginHours[(hour+23)%24] + profile.LoginHours[hour] + profile.LoginHours[(hour+1)%24]
+		if nearby == 0 {
+			deviations = append(deviations, BehaviorDeviation{
+				Type:     BehaviorDeviationLoginHour,
+				Message:  fmt.Sprintf("用户很少在 %02d:00 前后登录", hour),
+				Observed: fmt.Sprintf("%02d:00", hour),
+				Baseline: strings.Join(topLoginHours(profile.LoginHours, 3), ", "),
+				Score:    behaviorDeviationScores[BehaviorDeviationLoginHour],
+			})
+		}
+	}
+
+	if profile.EventCount >= behaviorMinEvents {
+		if network != "" && profile.Networks[network] == 0 {
+			deviations = append(deviations, BehaviorDeviation{
+				Type:     BehaviorDeviationNewNetwork,
+				Message:  "来自用户从未使用过的网络",
+				Observed: network,
+				Baseline: fmt.Sprintf("%d 个常用网络", len(profile.Networks)),
+				Score:    behaviorDeviationScores[BehaviorDeviationNewNetwork],
+			})
+		} else if observation.IPAddress != "" && profile.IPAddresses[observation.IPAddress] == 0 {
+			deviations = append(deviations, BehaviorDeviation{
+				Type:     BehaviorDeviationNewIP,
+				Message:  "来自用户从未使用过的IP地址",
+				Observed: observation.IPAddress,
+				Baseline: fmt.Sprintf("%d 个常用IP", len(profile.IPAddresses)),
+				Score:    behaviorDeviationScores[BehaviorDeviationNewIP],
+			})
+		}
+
+		if endpoint != "" && profile.Endpoints[endpoint] == 0 {
+			deviations = append(deviations, BehaviorDeviation{
+				Type:     BehaviorDeviationNewEndpoint,
+				Message:  "访问了用户从未访问过的接口",
+				Observed: endpoint,
+				Baseline: fmt.Sprintf("%d 个常用接口", len(profile.Endpoints)),
+				Score:    behaviorDeviationScores[BehaviorDeviationNewEndpoint],
+			})
+		}
+	}
+
+	if profile.RateSamples >= behaviorMinRateSamples {
+		// 本次事件计入后的当前分钟请求数
+		current := float64(1)
+		if observation.Time.Truncate(time.Minute).Equal(profile.CurrentMinute) {
+			current += float64(profile.CurrentCount)
+		}
+		z := (current - profile.RateMean) / math.Max(profile.RateStdDev(), 1)
+		if z >= behaviorRateZThreshold {
+			deviations = append(deviations, BehaviorDeviation{
+				Type:     BehaviorDeviationRequestRate,
+				Message:  fmt.Sprintf("请求频率为平时的 %.1f 倍", current/math.Max(profile.RateMean, 1)),
+				Observed: fmt.Sprintf("%.0f 次/分钟", current),
+				Baseline: fmt.Sprintf("%.1f±%.1f 次/分钟", profile.RateMean, profile.RateStdDev()),
+				Score:    math.Min(1, 0.5+(z-behaviorRateZThreshold)/(2*behaviorRateZThreshold)),
+			})
+		}
+	}
+
+	return deviations
+}
+
+// updateBehaviorProfile 将事件计入画像
+func updateBehaviorProfile(profile *Models.UserBehaviorProfile, observation AnomalyObservation, network, endpoint string) {
+	if profile.FirstSeenAt.IsZero() {
+		profile.FirstSeenAt = observation.Time
+	}
+	if observation.Time.After(profile.LastSeenAt) {
+		profile.LastSeenAt = observation.Time
+	}
+	profile.EventCount++
+
+	if observation.EventType == BehaviorEventLogin {
+		profile.LoginHours[observation.Time.Hour()]++
+		profile.LoginCount++
+	}
+	incrementBounded(profile.IPAddresses, observation.IPAddress)
+	incrementBounded(profile.Networks, network)
+	incrementBounded(profile.Endpoints, endpoint)
+
+	// 进入新的分钟时，将上一分钟的请求数计入频率分布（只统计有请求的分钟）
+	minute := observation.Time.Truncate(time.Minute)
+	if minute.After(profile.CurrentMinute) {
+		if profile.CurrentCount > 0 {
+			profile.RateSamples++
+			delta := float64(profile.CurrentCount) - profile.RateMean
+			profile.RateMean += delta / float64(profile.RateSamples)
+			profile.RateM2 += delta * (float64(profile.CurrentCount) - profile.RateMean)
+		}
+		profile.CurrentMinute = minute
+		profile.CurrentCount = 0
+	}
+	if minute.Equal(profile.CurrentMinute) {
+		profile.CurrentCount++
+	}
+}
+
+// incrementBounded 计数加一，条数超过上限时移除次数最少的一项
+func incrementBounded(counts map[string]int64, key string) {
+	if key == "" {
+		return
+	}
+	if _, ok := counts[key]; !ok && len(counts) >= behaviorMaxEntries {
+		var minKey string
+		var minCount int64 = math.MaxInt64
+		for k, v := range counts {
+			if v < minCount || (v == minCount && k < minKey) {
+				minKey, minCount = k, v
+			}
+		}
+		delete(counts, minKey)
+	}
+	counts[key]++
+}
+
+// combineDeviationScores 合并多个偏离分数，结果在0-1之间
+func combineDeviationScores(deviations []BehaviorDeviation) float64 {
+	normal := 1.0
+	for _, deviation := range deviations {
+		normal *= 1 - math.Min(math.Max(deviation.Score, 0), 1)
+	}
+	return 1 - normal
+}
+
+// topLoginHours 登录次数最多的几个小时
+func topLoginHours(hours [24]int64, n int) []string {
+	result := make([]string, 0, n)
+	used := [24]bool{}
+	for len(result) < n {
+		best := -1
+		for h, count := range hours {
+			if count > 0 && !used[h] && (best < 0 || count > hours[best]) {
+				best = h
+			}
+		}
+		if best < 0 {
+			break
+		}
+		used[best] = true
+		result = append(result, fmt.Sprintf("%02d:00", best))
+	}
+	return result
+}
+
+// behaviorEndpoint 接口标识，路径中的ID统一替换为 :id
+func behaviorEndpoint(action, resource string) string {
+	if resource == "" {
+		return ""
+	}
+	// 替换后的分隔符会被下一次匹配用到，需要重复替换直到不再变化
+	for {
+		replaced := behaviorPathIDPattern.ReplaceAllString(resource, "/:id$2")
+		if replaced == resource {
+			break
+		}
+		resource = replaced
+	}
+	return strings.TrimSpace(action + " " + resource)
+}
+
+// ipPrefixNetwork 默认网段解析：IPv4取/24，IPv6取/48
+func ipPrefixNetwork(ipAddress string) string {
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return ""
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String() + "/24"
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String() + "/48"
+}
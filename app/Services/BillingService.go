@@ -0,0 +1,526 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 套餐中的高级功能
+const (
+	PlanFeatureAnomalyDetection    = "anomaly_detection"     // 动态阈值和异常检测告警规则
+	PlanFeatureLongMetricRetention = "long_metric_retention" // 查询超过 BasicHistoryRange 的历史指标
+)
+
+// PlanFeatures 全部高级功能
+var PlanFeatures = []string{PlanFeatureAnomalyDetection, PlanFeatureLongMetricRetention}
+
+var (
+	// ErrBillingPlanNotFound 套餐不存在或不可订阅
+	ErrBillingPlanNotFound = errors.New("套餐不存在")
+	// ErrInvalidBillingPlan 套餐参数无效
+	ErrInvalidBillingPlan = errors.New("套餐参数无效")
+	// ErrBillingNotSubscribed 用户没有有效的订阅
+	ErrBillingNotSubscribed = errors.New("当前没有有效的订阅")
+	// ErrBillingAlreadySubscribed 用户已有有效的订阅，应更换套餐而不是重新订阅
+	ErrBillingAlreadySubscribed = errors.New("已有有效的订阅，请更换套餐")
+	// ErrInvalidProrationDate 按比例计费时间点不在允许的范围内
+	ErrInvalidProrationDate = errors.New("按比例计费时间点无效，请重新预览")
+)
+
+// prorationDateMaxAge 预览返回的按比例计费时间点在更换套餐时的有效期
+const prorationDateMaxAge = time.Hour
+
+// PlanFeatureError 当前套餐不包含所需的功能
+type PlanFeatureError struct {
+	Feature string
+	Plan    string // 当前套餐代码，未订阅时为空
+}
+
+func (e *PlanFeatureError) Error() string {
+	plan := e.Plan
+	if plan == "" {
+		plan = "免费版"
+	}
+	return fmt.Sprintf("当前套餐（%s）不包含 %s 功能，请升级套餐", plan, e.Feature)
+}
+
+// BillingPlanInput 创建或更新套餐的参数
+type BillingPlanInput struct {
+	Code                   string   `json:"code" binding:"required"`
+	Name                   string   `json:"name" binding:"required"`
+	StripePriceID          string   `json:"stripe_price_id" binding:"required"`
+	QuotaAPICalls          int64    `json:"quota_api_calls"`
+	QuotaStorageBytes      int64    `json:"quota_storage_bytes"`
+	QuotaMetricsIngested   int64    `json:"quota_metrics_ingested"`
+	QuotaNotificationsSent int64    `json:"quota_notifications_sent"`
+	Features               []string `json:"features"`
+	Active                 *bool    `json:"active"` // 为空时可以订阅
+}
+
+// BillingSubscription 用户当前的套餐和订阅
+type BillingSubscription struct {
+	Plan     *Models.BillingPlan    `json:"plan"`    // 当前套餐，未订阅时为空
+	Account  *Models.BillingAccount `json:"account"` // 计费账户，未创建 Stripe 客户时为空
+	Features []string               `json:"features"`
+}
+
+// BillingService 计费服务
+// 功能说明：
+// 1. 管理员维护套餐，每个套餐对应一个 Stripe 价格、一组用量限额和高级功能
+// 2. 用户通过 Stripe 支付页面订阅，订阅的创建、变更、续费失败和取消通过 Webhook 同步，重复和乱序的事件被忽略
+// 3. 更换套餐时按配置的方式按比例计费，可以先预览账单金额
+// 4. 作为 UsageQuotaProvider 为用户租户提供套餐中的限额，订阅变化后立即生效
+// 5. RequireFeature 检查用户的套餐是否包含高级功能，管理员不受限制
+type BillingService struct {
+	db       *gorm.DB
+	config   *Config.BillingConfig
+	stripe   StripeClient
+	metering *MeteringService
+	now      func() time.Time
+}
+
+// NewBillingService 创建计费服务
+//
+// config 为 nil 时使用全局配置；stripe 为 nil 时通过 Stripe REST API 调用。
+func NewBillingService(db *gorm.DB, config *Config.BillingConfig, stripe StripeClient) *BillingService {
+	if config == nil {
+		if config = Config.GetBillingConfig(); config == nil {
+			config = &Config.BillingConfig{
+				WebhookTolerance:  5 * time.Minute,
+				ProrationBehavior: Config.BillingProrationCreate,
+				BasicHistoryRange: 7 * 24 * time.Hour,
+			}
+		}
+	}
+	if stripe == nil {
+		stripe = NewStripeClient(config, nil)
+	}
+	return &BillingService{db: db, config: config, stripe: stripe, now: time.Now}
+}
+
+// SetMeteringService 设置用量计量服务，套餐中的限额通过该服务生效
+func (s *BillingService) SetMeteringService(metering *MeteringService) {
+	s.metering = metering
+	if metering != nil {
+		metering.SetQuotaProvider(s)
+	}
+}
+
+// SetClock 设置当前时间函数，用于测试
+func (s *BillingService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// Plans 获取套餐列表，includeInactive 为 false 时只返回可订阅的套餐
+func (s *BillingService) Plans(includeInactive bool) ([]Models.BillingPlan, error) {
+	query := s.db.Order("id ASC")
+	if !includeInactive {
+		query = query.Where("active = ?", true)
+	}
+	var plans []Models.BillingPlan
+	if err := query.Find(&plans).Error; err != nil {
+		return nil, err
+	}
+	return plans, nil
+}
+
+// SavePlan 按套餐代码创建或更新套餐，限额和功能的变化对已订阅的用户立即生效
+func (s *BillingService) SavePlan(input BillingPlanInput) (*Models.BillingPlan, error) {
+	code, name, priceID := strings.TrimSpace(input.Code), strings.TrimSpace(input.Name), strings.TrimSpace(input.StripePriceID)
+	if code == "" || name == "" || priceID == "" {
+		return nil, fmt.Errorf("%w：套餐代码、名称和 Stripe 价格ID不能为空", ErrInvalidBillingPlan)
+	}
+	if input.QuotaAPICalls < 0 || input.QuotaStorageBytes < 0 || input.QuotaMetricsIngested < 0 || input.QuotaNotificationsSent < 0 {
+		return nil, fmt.Errorf("%w：限额不能为负数", ErrInvalidBillingPlan)
+	}
+	for _, feature := range input.Features {
+		if !slices.Contains(PlanFeatures, feature) {
+			return nil, fmt.Errorf("%w：不支持的功能 %s", ErrInvalidBillingPlan, feature)
+		}
+	}
+
+	plan := Models.BillingPlan{Code: code}
+	if err := s.db.Where("code = ?", code).First(&plan).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	plan.Name = name
+	plan.StripePriceID = priceID
+	plan.QuotaAPICalls = input.QuotaAPICalls
+	plan.QuotaStorageBytes = input.QuotaStorageBytes
+	plan.QuotaMetricsIngested = input.QuotaMetricsIngested
+	plan.QuotaNotificationsSent = input.QuotaNotificationsSent
+	plan.Features = strings.Join(input.Features, ",")
+	plan.Active = input.Active == nil || *input.Active
+	if err := s.db.Save(&plan).Error; err != nil {
+		return nil, err
+	}
+	if s.metering != nil {
+		s.metering.ForgetLimits("")
+	}
+	return &plan, nil
+}
+
+// Subscription 获取用户当前的套餐、订阅和可用功能
+func (s *BillingService) Subscription(userID uint) (*BillingSubscription, error) {
+	account, plan, err := s.current(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &BillingSubscription{Plan: plan, Account: account, Features: s.features(plan)}, nil
+}
+
+// Checkout 创建订阅支付页面，返回支付页面地址
+//
+// 首次订阅时创建 Stripe 客户；订阅在支付完成后通过 Webhook 生效。
+func (s *BillingService) Checkout(ctx context.Context, userID uint, planCode string) (string, error) {
+	plan, err := s.subscribablePlan(planCode)
+	if err != nil {
+		return "", err
+	}
+	account, current, err := s.current(userID)
+	if err != nil {
+		return "", err
+	}
+	if current != nil {
+		return "", ErrBillingAlreadySubscribed
+	}
+	if account == nil {
+		if account, err = s.createAccount(ctx, userID); err != nil {
+			return "", err
+		}
+	}
+	return s.stripe.CreateCheckoutSession(ctx, account.StripeCustomerID, plan.StripePriceID, userID)
+}
+
+// PreviewChange 预览更换套餐后的账单
+func (s *BillingService) PreviewChange(ctx context.Context, userID uint, planCode string) (*BillingProrationPreview, error) {
+	account, plan, err := s.changeTarget(userID, planCode)
+	if err != nil {
+		return nil, err
+	}
+	preview, err := s.stripe.PreviewSubscriptionPrice(ctx, account.StripeCustomerID, account.StripeSubscriptionID,
+		account.StripeItemID, plan.StripePriceID, s.config.ProrationBehavior, s.now().UTC().Truncate(time.Second))
+	if err != nil {
+		return nil, err
+	}
+	preview.Plan = plan.Code
+	return preview, nil
+}
+
+// ChangePlan 更换套餐，按配置的方式按比例计费
+//
+// prorationDate 为预览返回的时间点，使实际金额与预览一致；为零值时使用当前时间。
+// 新套餐的限额和功能立即生效，不等待 Webhook。
+func (s *BillingService) ChangePlan(ctx context.Context, userID uint, planCode string, prorationDate time.Time) (*Models.BillingAccount, error) {
+	account, plan, err := s.changeTarget(userID, planCode)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now().UTC()
+	if prorationDate.IsZero() {
+		prorationDate = now
+	} else if prorationDate.After(now) || now.Sub(prorationDate) > prorationDateMaxAge {
+		return nil, ErrInvalidProrationDate
+	}
+	subscription, err := s.stripe.UpdateSubscriptionPrice(ctx, account.StripeSubscriptionID, account.StripeItemID,
+		plan.StripePriceID, s.config.ProrationBehavior, prorationDate.Truncate(time.Second))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(s.db, account, subscription); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// Cancel 在当前计费周期结束时取消订阅，周期结束前仍可使用当前套餐
+func (s *BillingService) Cancel(ctx context.Context, userID uint) (*Models.BillingAccount, error) {
+	account, plan, err := s.current(userID)
+	if err != nil {
+		return nil, err
+	}
+	if plan == nil {
+		return nil, ErrBillingNotSubscribed
+	}
+	subscription, err := s.stripe.CancelSubscription(ctx, account.StripeSubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(s.db, account, subscription); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// HandleWebhook 校验签名并处理 Stripe Webhook 事件
+//
+// 同步 customer.subscription.created、updated、deleted 事件，其他事件只记录；
+// 已处理的事件直接返回成功，早于最近一次同步的订阅事件被忽略。处理失败时返回错误，由 Stripe 重新投递。
+func (s *BillingService) HandleWebhook(payload []byte, signature string) error {
+	if err := VerifyStripeSignature(payload, signature, s.config.StripeWebhookSecret, s.config.WebhookTolerance, s.now()); err != nil {
+		return err
+	}
+	var event stripeEvent
+	if err := json.Unmarshal(payload, &event); err != nil || event.ID == "" {
+		return fmt.Errorf("%w：事件格式错误", ErrInvalidStripeSignature)
+	}
+
+	var changedUser uint
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&Models.BillingEvent{StripeEventID: event.ID, Type: event.Type})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		switch event.Type {
+		case "customer.subscription.created", "customer.subscription.updated", "customer.subscription.deleted":
+		default:
+			return nil
+		}
+		var object stripeSubscriptionObject
+		if err := json.Unmarshal(event.Data.Object, &object); err != nil {
+			return err
+		}
+		subscription := object.toSubscription()
+
+		var account Models.BillingAccount
+		err := tx.Where("stripe_customer_id = ?", subscription.CustomerID).First(&account).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("收到未知 Stripe 客户的订阅事件，已忽略: event=%s, customer=%s", event.ID, subscription.CustomerID)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		eventAt := time.Unix(event.Created, 0).UTC()
+		if account.StripeEventAt != nil && eventAt.Before(*account.StripeEventAt) {
+			return nil
+		}
+		// 同一客户订阅了新的订阅后，旧订阅的事件不再覆盖当前订阅
+		if account.StripeSubscriptionID != "" && account.StripeSubscriptionID != subscription.ID && event.Type != "customer.subscription.created" {
+			return nil
+		}
+		if event.Type == "customer.subscription.deleted" {
+			subscription.Status = Models.BillingStatusCanceled
+		}
+		account.StripeEventAt = &eventAt
+		changedUser = account.UserID
+		return s.apply(tx, &account, subscription)
+	})
+	if err != nil {
+		return err
+	}
+	if changedUser != 0 && s.metering != nil {
+		s.metering.ForgetLimits(UserTenant(changedUser))
+	}
+	return nil
+}
+
+// RequireFeature 检查用户的套餐是否包含功能，不包含时返回 *PlanFeatureError，管理员不受限制
+func (s *BillingService) RequireFeature(userID uint, role, feature string) error {
+	if role == "admin" {
+		return nil
+	}
+	_, plan, err := s.current(userID)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(s.features(plan), feature) {
+		return nil
+	}
+	planError := &PlanFeatureError{Feature: feature}
+	if plan != nil {
+		planError.Plan = plan.Code
+	}
+	return planError
+}
+
+// RequireHistoryRange 检查用户是否可以查询 window 范围的历史指标
+//
+// 不超过 BasicHistoryRange 时不需要长期指标保留功能，超过时返回 *PlanFeatureError。
+func (s *BillingService) RequireHistoryRange(userID uint, role string, window time.Duration) error {
+	if window <= s.config.BasicHistoryRange {
+		return nil
+	}
+	return s.RequireFeature(userID, role, PlanFeatureLongMetricRetention)
+}
+
+// TenantQuota 实现 UsageQuotaProvider，订阅了套餐的用户使用套餐中的限额
+func (s *BillingService) TenantQuota(tenant, metric string) (int64, bool, error) {
+	userID, ok := ParseUserTenant(tenant)
+	if !ok {
+		return 0, false, nil
+	}
+	_, plan, err := s.current(userID)
+	if err != nil || plan == nil {
+		return 0, false, err
+	}
+	switch metric {
+	case UsageMetricAPICalls:
+		return plan.QuotaAPICalls, true, nil
+	case UsageMetricStorageBytes:
+		return plan.QuotaStorageBytes, true, nil
+	case UsageMetricMetricsIngested:
+		return plan.QuotaMetricsIngested, true, nil
+	case UsageMetricNotificationsSent:
+		return plan.QuotaNotificationsSent, true, nil
+	}
+	return 0, false, nil
+}
+
+// current 获取用户的计费账户和当前有效的套餐，没有计费账户或订阅已失效时对应返回值为 nil
+func (s *BillingService) current(userID uint) (*Models.BillingAccount, *Models.BillingPlan, error) {
+	var account Models.BillingAccount
+	if err := s.db.Where("user_id = ?", userID).First(&account).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	if account.PlanCode == "" || !billingStatusActive(account.Status) {
+		return &account, nil, nil
+	}
+	var plan Models.BillingPlan
+	if err := s.db.Where("code = ?", account.PlanCode).First(&plan).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &account, nil, nil
+		}
+		return nil, nil, err
+	}
+	return &account, &plan, nil
+}
+
+// changeTarget 获取更换套餐的计费账户和目标套餐
+func (s *BillingService) changeTarget(userID uint, planCode string) (*Models.BillingAccount, *Models.BillingPlan, error) {
+	plan, err := s.subscribablePlan(planCode)
+	if err != nil {
+		return nil, nil, err
+	}
+	account, current, err := s.current(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if current == nil || account.StripeItemID == "" {
+		return nil, nil, ErrBillingNotSubscribed
+	}
+	if current.Code == plan.Code {
+		return nil, nil, fmt.Errorf("%w：已是该套餐", ErrInvalidBillingPlan)
+	}
+	return account, plan, nil
+}
+
+// subscribablePlan 获取可订阅的套餐
+func (s *BillingService) subscribablePlan(code string) (*Models.BillingPlan, error) {
+	var plan Models.BillingPlan
+	if err := s.db.Where("code = ? AND active = ?", code, true).First(&plan).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBillingPlanNotFound
+		}
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// createAccount 创建 Stripe 客户和计费账户
+func (s *BillingService) createAccount(ctx context.Context, userID uint) (*Models.BillingAccount, error) {
+	var user Models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	customerID, err := s.stripe.CreateCustomer(ctx, user.Email, userID)
+	if err != nil {
+		return nil, err
+	}
+	account := &Models.BillingAccount{UserID: userID, StripeCustomerID: customerID}
+	if err := s.db.Create(account).Error; err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// apply 将 Stripe 订阅写入计费账户，价格对应的套餐不存在时视为未订阅
+func (s *BillingService) apply(db *gorm.DB, account *Models.BillingAccount, subscription *StripeSubscription) error {
+	planCode := ""
+	if subscription.Status != Models.BillingStatusCanceled {
+		var plan Models.BillingPlan
+		err := db.Where("stripe_price_id = ?", subscription.PriceID).First(&plan).Error
+		switch {
+		case err == nil:
+			planCode = plan.Code
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			log.Printf("Stripe 订阅的价格没有对应的套餐: subscription=%s, price=%s", subscription.ID, subscription.PriceID)
+		default:
+			return err
+		}
+	}
+
+	account.StripeSubscriptionID = subscription.ID
+	account.StripeItemID = subscription.ItemID
+	account.PlanCode = planCode
+	account.Status = subscription.Status
+	account.CancelAtPeriodEnd = subscription.CancelAtPeriodEnd
+	account.CurrentPeriodEnd = nil
+	if !subscription.CurrentPeriodEnd.IsZero() {
+		periodEnd := subscription.CurrentPeriodEnd
+		account.CurrentPeriodEnd = &periodEnd
+	}
+	if err := db.Save(account).Error; err != nil {
+		return err
+	}
+	if s.metering != nil && db == s.db {
+		s.metering.ForgetLimits(UserTenant(account.UserID))
+	}
+	return nil
+}
+
+// features 套餐包含的功能，未订阅时为 FreeFeatures
+func (s *BillingService) features(plan *Models.BillingPlan) []string {
+	features := splitNotificationList(s.config.FreeFeatures)
+	if plan != nil {
+		for _, feature := range splitNotificationList(plan.Features) {
+			if !slices.Contains(features, feature) {
+				features = append(features, feature)
+			}
+		}
+	}
+	return features
+}
+
+// billingStatusActive 订阅状态是否可以使用套餐
+func billingStatusActive(status string) bool {
+	return status == Models.BillingStatusActive || status == Models.BillingStatusTrialing || status == Models.BillingStatusPastDue
+}
+
+var (
+	defaultBillingService   *BillingService
+	defaultBillingServiceMu sync.RWMutex
+)
+
+// SetDefaultBillingService 设置全局计费服务
+func SetDefaultBillingService(service *BillingService) {
+	defaultBillingServiceMu.Lock()
+	defer defaultBillingServiceMu.Unlock()
+	defaultBillingService = service
+}
+
+// DefaultBillingService 获取全局计费服务，未启用计费时返回 nil，此时所有功能都可以使用
+func DefaultBillingService() *BillingService {
+	defaultBillingServiceMu.RLock()
+	defer defaultBillingServiceMu.RUnlock()
+	return defaultBillingService
+}
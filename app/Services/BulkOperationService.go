@@ -0,0 +1,892 @@
+package Services
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/I18n"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Utils"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrBulkJobEmpty 批量任务没有数据
+	ErrBulkJobEmpty = errors.New("没有需要处理的数据")
+	// ErrBulkJobTooLarge 批量任务行数超过限制
+	ErrBulkJobTooLarge = errors.New("批量任务行数超过限制")
+	// ErrBulkInvalidRole 无效的角色
+	ErrBulkInvalidRole = errors.New("无效的角色")
+	// ErrBulkInvalidStatus 无效的用户状态
+	ErrBulkInvalidStatus = errors.New("用户状态只能是0或1")
+	// ErrBulkImportFormat 不支持的导入格式
+	ErrBulkImportFormat = errors.New("不支持的导入格式，只支持csv和json")
+
+	// errBulkJobStopped 服务停止时中断的任务，重新放回队列
+	errBulkJobStopped = errors.New("批量任务被中断")
+)
+
+// bulkUserRoles 批量操作允许分配的角色
+var bulkUserRoles = []string{"admin", "user"}
+
+// bulkPhonePattern E.164格式手机号
+var bulkPhonePattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// BulkUserRow 导入的一行用户数据
+// 功能说明：
+// 1. Password 为空时生成随机密码并要求首次登录后修改（用户通过找回密码设置自己的密码）
+// 2. Role 为空时为 user，Status 为空时为启用
+// 3. Row 为文件中的行号，用于错误明细定位
+type BulkUserRow struct {
+	Row      int    `json:"row"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password,omitempty"`
+	Role     string `json:"role,omitempty"`
+	Status   *int   `json:"status,omitempty"`
+	Phone    string `json:"phone,omitempty"`
+	Locale   string `json:"locale,omitempty"`
+}
+
+// bulkUserUpdate 批量角色分配和启用/禁用任务的参数
+type bulkUserUpdate struct {
+	UserIDs []uint `json:"user_ids"`
+	Role    string `json:"role,omitempty"`
+	Status  int    `json:"status"`
+}
+
+// BulkJobFilter 批量任务查询条件
+type BulkJobFilter struct {
+	Type   string
+	Status string
+	Page   int
+	Limit  int
+}
+
+// BulkOperationService 用户和角色批量操作服务
+// 功能说明：
+// 1. 支持CSV/JSON导入用户（可只校验不写入）、批量分配角色、批量启用/禁用用户、批量删除用户
+// 2. 任务先写入数据库再由后台协程异步执行，服务重启后恢复未完成的任务
+// 3. 每 ProgressEvery 行在一个事务中执行并保存进度和行级错误，中断后从上次保存的位置继续，不会重复导入
+// 4. 修改角色、禁用或删除用户后撤销该用户已签发的token，使新的权限立即生效
+type BulkOperationService struct {
+	db     *gorm.DB
+	config Config.BulkConfig
+	tokens *TokenBlacklistService
+
+	mu      sync.Mutex
+	started bool
+	queue   chan uint
+	stopCh  chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewBulkOperationService 创建批量操作服务
+//
+// config 为 nil 时使用全局配置，未设置的值使用默认值。
+func NewBulkOperationService(db *gorm.DB, config *Config.BulkConfig) *BulkOperationService {
+	var cfg Config.BulkConfig
+	if config != nil {
+		cfg = *config
+	} else if globalConfig := Config.GetBulkConfig(); globalConfig != nil {
+		cfg = *globalConfig
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 2
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 100
+	}
+	if cfg.MaxRows <= 0 {
+		cfg.MaxRows = 10000
+	}
+	if cfg.MaxUploadSize <= 0 {
+		cfg.MaxUploadSize = 10 << 20
+	}
+	if cfg.ProgressEvery <= 0 {
+		cfg.ProgressEvery = 100
+	}
+
+	return &BulkOperationService{
+		db:     db,
+		config: cfg,
+	}
+}
+
+// SetTokenBlacklistService 设置token黑名单服务，用于角色变更和禁用后强制下线
+func (s *BulkOperationService) SetTokenBlacklistService(tokens *TokenBlacklistService) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = tokens
+}
+
+// MaxUploadSize 导入文件最大字节数
+func (s *BulkOperationService) MaxUploadSize() int64 {
+	return s.config.MaxUploadSize
+}
+
+// Start 启动执行协程，并恢复上次未完成的任务
+func (s *BulkOperationService) Start() {
+	s.mu.Lock()
+	if s.started {
+		s.mu.Unlock()
+		return
+	}
+	s.queue = make(chan uint, s.config.QueueSize)
+	s.stopCh = make(chan struct{})
+	s.started = true
+	s.mu.Unlock()
+
+	// 上次退出时正在执行的任务已保存进度，重新入队后从进度位置继续
+	s.db.Model(&Models.BulkJob{}).Where("status = ?", Models.BulkJobRunning).
+		Update("status", Models.BulkJobQueued)
+
+	for i := 0; i < s.config.Workers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+
+	s.wg.Add(1)
+	go s.sweeper()
+}
+
+// Stop 停止执行协程，正在执行的任务保存进度后放回队列
+func (s *BulkOperationService) Stop() {
+	s.mu.Lock()
+	if !s.started {
+		s.mu.Unlock()
+		return
+	}
+	s.started = false
+	close(s.stopCh)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *BulkOperationService) worker() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case id := <-s.queue:
+			if err := s.process(id); err != nil && !errors.Is(err, errBulkJobStopped) {
+				log.Printf("执行批量任务失败: id=%d, error=%v", id, err)
+			}
+		}
+	}
+}
+
+// sweeper 定期把待执行的任务放回队列，处理队列满和服务重启的情况
+func (s *BulkOperationService) sweeper() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		s.enqueuePending()
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *BulkOperationService) enqueuePending() {
+	var ids []uint
+	err := s.db.Model(&Models.BulkJob{}).Where("status = ?", Models.BulkJobQueued).
+		Order("id").Limit(cap(s.queue)).Pluck("id", &ids).Error
+	if err != nil {
+		log.Printf("查询待执行批量任务失败: %v", err)
+		return
+	}
+	for _, id := range ids {
+		s.enqueue(id)
+	}
+}
+
+// enqueue 放入执行队列，服务未启动或队列已满时任务保留在数据库中，由 sweeper 稍后处理
+func (s *BulkOperationService) enqueue(id uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.started {
+		return
+	}
+	select {
+	case s.queue <- id:
+	default:
+		log.Printf("批量任务队列已满，稍后执行: id=%d", id)
+	}
+}
+
+// stopping 服务是否正在停止
+func (s *BulkOperationService) stopping() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.started
+}
+
+// ParseUserImport 解析导入文件
+// 功能说明：
+// 1. csv 格式第一行为表头，必须包含 username、email 列，可选 password、role、status、phone、locale 列
+// 2. json 格式为用户数组，或 {"users": [...]} 对象
+// 3. 只检查文件格式，字段内容在任务执行时逐行校验并记录错误明细
+func (s *BulkOperationService) ParseUserImport(format string, r io.Reader) ([]BulkUserRow, error) {
+	data, err := io.ReadAll(io.LimitReader(r, s.config.MaxUploadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.config.MaxUploadSize {
+		return nil, fmt.Errorf("导入文件超过大小限制: %d 字节", s.config.MaxUploadSize)
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	var rows []BulkUserRow
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "csv":
+		rows, err = parseBulkUserCSV(data)
+	case "json":
+		rows, err = parseBulkUserJSON(data)
+	default:
+		return nil, ErrBulkImportFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkRowCount(len(rows)); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func parseBulkUserCSV(data []byte) ([]BulkUserRow, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, ErrBulkJobEmpty
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析CSV表头失败: %v", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"username", "email"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("CSV缺少 %s 列", required)
+		}
+	}
+
+	rows := make([]BulkUserRow, 0)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析CSV失败: %v", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		value := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		row := BulkUserRow{
+			Row:      line,
+			Username: value("username"),
+			Email:    value("email"),
+			Password: value("password"),
+			Role:     value("role"),
+			Phone:    value("phone"),
+			Locale:   value("locale"),
+		}
+		if status := value("status"); status != "" {
+			parsed, err := parseBulkStatus(status)
+			if err != nil {
+				return nil, fmt.Errorf("第%d行: %v", line, err)
+			}
+			row.Status = &parsed
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseBulkStatus 解析CSV中的状态，支持 1/0、active/disabled、enabled
+func parseBulkStatus(value string) (int, error) {
+	switch strings.ToLower(value) {
+	case "1", "active", "enabled":
+		return 1, nil
+	case "0", "disabled":
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("%w: %s", ErrBulkInvalidStatus, value)
+	}
+}
+
+func parseBulkUserJSON(data []byte) ([]BulkUserRow, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, ErrBulkJobEmpty
+	}
+
+	var rows []BulkUserRow
+	if data[0] == '{' {
+		var wrapper struct {
+			Users []BulkUserRow `json:"users"`
+		}
+		if err := json.Unmarshal(data, &wrapper); err != nil {
+			return nil, fmt.Errorf("解析JSON失败: %v", err)
+		}
+		rows = wrapper.Users
+	} else if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("解析JSON失败: %v", err)
+	}
+
+	for i := range rows {
+		rows[i].Row = i + 1
+	}
+	return rows, nil
+}
+
+func (s *BulkOperationService) checkRowCount(n int) error {
+	if n == 0 {
+		return ErrBulkJobEmpty
+	}
+	if n > s.config.MaxRows {
+		return fmt.Errorf("%w: %d 行，最多 %d 行", ErrBulkJobTooLarge, n, s.config.MaxRows)
+	}
+	return nil
+}
+
+// SubmitUserImport 提交用户导入任务，dryRun 为 true 时只校验不写入
+func (s *BulkOperationService) SubmitUserImport(rows []BulkUserRow, dryRun bool, operatorID uint) (*Models.BulkJob, error) {
+	if err := s.checkRowCount(len(rows)); err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		if rows[i].Row == 0 {
+			rows[i].Row = i + 1
+		}
+	}
+	return s.submit(Models.BulkJobUserImport, rows, len(rows), dryRun, operatorID)
+}
+
+// SubmitRoleAssignment 提交批量分配角色任务
+func (s *BulkOperationService) SubmitRoleAssignment(userIDs []uint, role string, operatorID uint) (*Models.BulkJob, error) {
+	if !containsString(bulkUserRoles, role) {
+		return nil, fmt.Errorf("%w: %s", ErrBulkInvalidRole, role)
+	}
+	userIDs = uniqueUserIDs(userIDs)
+	if err := s.checkRowCount(len(userIDs)); err != nil {
+		return nil, err
+	}
+	return s.submit(Models.BulkJobRoleAssign, bulkUserUpdate{UserIDs: userIDs, Role: role}, len(userIDs), false, operatorID)
+}
+
+// SubmitStatusChange 提交批量启用(1)/禁用(0)用户任务
+func (s *BulkOperationService) SubmitStatusChange(userIDs []uint, status int, operatorID uint) (*Models.BulkJob, error) {
+	if status != 0 && status != 1 {
+		return nil, ErrBulkInvalidStatus
+	}
+	userIDs = uniqueUserIDs(userIDs)
+	if err := s.checkRowCount(len(userIDs)); err != nil {
+		return nil, err
+	}
+	return s.submit(Models.BulkJobUserStatus, bulkUserUpdate{UserIDs: userIDs, Status: status}, len(userIDs), false, operatorID)
+}
+
+// SubmitUserDeletion 提交批量删除用户任务，用户按 DeletionService 的删除策略进入回收站
+func (s *BulkOperationService) SubmitUserDeletion(userIDs []uint, operatorID uint) (*Models.BulkJob, error) {
+	userIDs = uniqueUserIDs(userIDs)
+	if err := s.checkRowCount(len(userIDs)); err != nil {
+		return nil, err
+	}
+	return s.submit(Models.BulkJobUserDelete, bulkUserUpdate{UserIDs: userIDs}, len(userIDs), false, operatorID)
+}
+
+// uniqueUserIDs 去除重复和无效的用户ID，保持原有顺序
+func uniqueUserIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	return result
+}
+
+func (s *BulkOperationService) submit(jobType string, payload interface{}, total int, dryRun bool, operatorID uint) (*Models.BulkJob, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	job := &Models.BulkJob{
+		Type:      jobType,
+		Status:    Models.BulkJobQueued,
+		DryRun:    dryRun,
+		Payload:   string(data),
+		Total:     total,
+		CreatedBy: operatorID,
+	}
+	if err := s.db.Create(job).Error; err != nil {
+		return nil, err
+	}
+	s.enqueue(job.ID)
+	return job, nil
+}
+
+// GetJob 获取批量任务
+func (s *BulkOperationService) GetJob(id uint) (*Models.BulkJob, error) {
+	var job Models.BulkJob
+	if err := s.db.First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ListJobs 查询批量任务
+func (s *BulkOperationService) ListJobs(filter BulkJobFilter) ([]Models.BulkJob, int64, error) {
+	query := s.db.Model(&Models.BulkJob{})
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var jobs []Models.BulkJob
+	err := query.Order("id DESC").Offset((filter.Page - 1) * filter.Limit).Limit(filter.Limit).Find(&jobs).Error
+	return jobs, total, err
+}
+
+// ListJobErrors 查询批量任务的行级错误，按行号排序
+func (s *BulkOperationService) ListJobErrors(jobID uint, page, limit int) ([]Models.BulkJobError, int64, error) {
+	query := s.db.Model(&Models.BulkJobError{}).Where("job_id = ?", jobID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rowErrors []Models.BulkJobError
+	err := query.Order("row_no, id").Offset((page - 1) * limit).Limit(limit).Find(&rowErrors).Error
+	return rowErrors, total, err
+}
+
+// process 执行批量任务，只有待执行状态的任务会被领取，避免同一任务被多个协程重复执行
+func (s *BulkOperationService) process(id uint) error {
+	result := s.db.Model(&Models.BulkJob{}).
+		Where("id = ? AND status = ?", id, Models.BulkJobQueued).
+		Updates(map[string]interface{}{
+			"status":     Models.BulkJobRunning,
+			"started_at": gorm.Expr("COALESCE(started_at, ?)", time.Now()),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	var job Models.BulkJob
+	if err := s.db.First(&job, id).Error; err != nil {
+		return err
+	}
+
+	var err error
+	switch job.Type {
+	case Models.BulkJobUserImport:
+		err = s.runUserImport(&job)
+	case Models.BulkJobRoleAssign, Models.BulkJobUserStatus:
+		err = s.runUserUpdate(&job)
+	case Models.BulkJobUserDelete:
+		err = s.runUserDelete(&job)
+	default:
+		err = fmt.Errorf("未知的批量任务类型: %s", job.Type)
+	}
+	s.finish(&job, err)
+	return err
+}
+
+func (s *BulkOperationService) finish(job *Models.BulkJob, cause error) {
+	updates := map[string]interface{}{}
+	switch {
+	case errors.Is(cause, errBulkJobStopped):
+		updates["status"] = Models.BulkJobQueued
+	case cause != nil:
+		updates["status"] = Models.BulkJobFailed
+		updates["last_error"] = cause.Error()
+		updates["finished_at"] = time.Now()
+	default:
+		updates["status"] = Models.BulkJobCompleted
+		updates["finished_at"] = time.Now()
+	}
+	// 导入任务结束后清空待处理数据，避免明文密码留在数据库中
+	if job.Type == Models.BulkJobUserImport && updates["status"] != Models.BulkJobQueued {
+		updates["payload"] = ""
+	}
+	if err := s.db.Model(&Models.BulkJob{}).Where("id = ?", job.ID).Updates(updates).Error; err != nil {
+		log.Printf("更新批量任务状态失败: id=%d, error=%v", job.ID, err)
+	}
+}
+
+// runRows 从 job.Processed 开始逐行处理，每 ProgressEvery 行在一个事务中执行并保存进度和错误明细
+//
+// handle 在事务中处理第 index 行，返回该行的错误明细；afterCommit 在每段事务提交后执行（如撤销token）。
+// 单行失败只回滚该行的修改，不影响同一段的其他行。
+func (s *BulkOperationService) runRows(job *Models.BulkJob, handle func(tx *gorm.DB, index int) []Models.BulkJobError, afterCommit func()) error {
+	for job.Processed < job.Total {
+		if s.stopping() {
+			return errBulkJobStopped
+		}
+
+		end := min(job.Processed+s.config.ProgressEvery, job.Total)
+		progress := *job
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			var rowErrors []Models.BulkJobError
+			for index := progress.Processed; index < end; index++ {
+				errs := handle(tx, index)
+				for i := range errs {
+					errs[i].JobID = job.ID
+					errs[i].Message = truncateUTF8(errs[i].Message, 500)
+				}
+				if len(errs) > 0 {
+					progress.Failed++
+					rowErrors = append(rowErrors, errs...)
+				} else {
+					progress.Succeeded++
+				}
+				progress.Processed++
+			}
+
+			if len(rowErrors) > 0 {
+				if err := tx.CreateInBatches(rowErrors, 100).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Model(&Models.BulkJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+				"processed": progress.Processed,
+				"succeeded": progress.Succeeded,
+				"failed":    progress.Failed,
+			}).Error
+		})
+		if err != nil {
+			return err
+		}
+
+		*job = progress
+		if afterCommit != nil {
+			afterCommit()
+		}
+	}
+	return nil
+}
+
+// runUserImport 执行用户导入任务
+func (s *BulkOperationService) runUserImport(job *Models.BulkJob) error {
+	var rows []BulkUserRow
+	if err := json.Unmarshal([]byte(job.Payload), &rows); err != nil {
+		return fmt.Errorf("解析任务数据失败: %v", err)
+	}
+	job.Total = len(rows)
+
+	// 文件内的重复检查需要包含已处理的行，中断后继续执行时先恢复
+	usernames := make(map[string]int)
+	emails := make(map[string]int)
+	remember := func(row BulkUserRow) {
+		if name := strings.ToLower(strings.TrimSpace(row.Username)); name != "" {
+			if _, ok := usernames[name]; !ok {
+				usernames[name] = row.Row
+			}
+		}
+		if email := strings.ToLower(strings.TrimSpace(row.Email)); email != "" {
+			if _, ok := emails[email]; !ok {
+				emails[email] = row.Row
+			}
+		}
+	}
+	for _, row := range rows[:min(job.Processed, len(rows))] {
+		remember(row)
+	}
+
+	return s.runRows(job, func(tx *gorm.DB, index int) []Models.BulkJobError {
+		row := rows[index]
+		user, rowErrors := validateBulkUserRow(tx, row, usernames, emails)
+		remember(row)
+		if len(rowErrors) > 0 || job.DryRun {
+			return rowErrors
+		}
+
+		hashed, err := Utils.HashPassword(user.Password)
+		if err != nil {
+			return []Models.BulkJobError{bulkRowError(row, "password", "密码哈希失败: "+err.Error())}
+		}
+		user.Password = hashed
+		// status 列有默认值1，创建时零值会被忽略并回填为1，禁用状态需要单独更新
+		status := user.Status
+		err = tx.Transaction(func(rowTx *gorm.DB) error {
+			if err := rowTx.Create(user).Error; err != nil {
+				return err
+			}
+			if status == 0 {
+				if err := rowTx.Model(user).Update("status", 0).Error; err != nil {
+					return err
+				}
+			}
+			return publishUserCreated(rowTx, user, "bulk_import")
+		})
+		if err != nil {
+			return []Models.BulkJobError{bulkRowError(row, "", "创建用户失败: "+err.Error())}
+		}
+		return nil
+	}, nil)
+}
+
+// validateBulkUserRow 校验导入的一行数据，通过时返回待创建的用户，Password 为明文，写入前再哈希（只校验时不需要哈希）
+func validateBulkUserRow(tx *gorm.DB, row BulkUserRow, usernames, emails map[string]int) (*Models.User, []Models.BulkJobError) {
+	var rowErrors []Models.BulkJobError
+	fail := func(field, message string) {
+		rowErrors = append(rowErrors, bulkRowError(row, field, message))
+	}
+
+	username := strings.TrimSpace(row.Username)
+	switch length := utf8.RuneCountInString(username); {
+	case length < 3 || length > 50:
+		fail("username", "用户名长度必须为3-50个字符")
+	case strings.IndexFunc(username, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) >= 0:
+		fail("username", "用户名不能包含空白字符")
+	default:
+		if previous, ok := usernames[strings.ToLower(username)]; ok {
+			fail("username", fmt.Sprintf("用户名与第%d行重复", previous))
+		} else if bulkUserExists(tx, "username = ?", username) {
+			fail("username", "用户名已存在")
+		}
+	}
+
+	email := strings.ToLower(strings.TrimSpace(row.Email))
+	if address, err := mail.ParseAddress(email); err != nil || address.Address != email || len(email) > 100 {
+		fail("email", "邮箱格式无效")
+	} else if previous, ok := emails[email]; ok {
+		fail("email", fmt.Sprintf("邮箱与第%d行重复", previous))
+	} else if bulkUserExists(tx, "email = ?", email) {
+		fail("email", "邮箱已存在")
+	}
+
+	mustChange := false
+	password := row.Password
+	if password == "" {
+		password = randomBulkPassword()
+		mustChange = true
+	} else if valid, reasons := Utils.ValidatePasswordStrength(password); !valid {
+		fail("password", "密码强度不足: "+strings.Join(reasons, "; "))
+	}
+
+	role := strings.ToLower(strings.TrimSpace(row.Role))
+	if role == "" {
+		role = "user"
+	} else if !containsString(bulkUserRoles, role) {
+		fail("role", "无效的角色: "+row.Role)
+	}
+
+	status := 1
+	if row.Status != nil {
+		status = *row.Status
+		if status != 0 && status != 1 {
+			fail("status", ErrBulkInvalidStatus.Error())
+		}
+	}
+
+	phone := strings.NewReplacer(" ", "", "-", "").Replace(row.Phone)
+	if phone != "" && !bulkPhonePattern.MatchString(phone) {
+		fail("phone", "手机号必须为E.164格式，如 +8613800138000")
+	}
+
+	if len(rowErrors) > 0 {
+		return nil, rowErrors
+	}
+
+	now := time.Now()
+	user := &Models.User{
+		Username:           username,
+		Email:              email,
+		Password:           password,
+		Role:               role,
+		Status:             status,
+		Phone:              phone,
+		Locale:             I18n.NormalizeLocale(row.Locale),
+		PasswordChangedAt:  &now,
+		MustChangePassword: mustChange,
+	}
+	return user, nil
+}
+
+// bulkUserExists 检查用户是否已存在，包含已软删除的用户（唯一索引仍然生效）
+func bulkUserExists(tx *gorm.DB, condition string, value string) bool {
+	var count int64
+	tx.Unscoped().Model(&Models.User{}).Where(condition, value).Count(&count)
+	return count > 0
+}
+
+// randomBulkPassword 生成随机密码，用于未提供密码的导入用户
+func randomBulkPassword() string {
+	buf := make([]byte, 18)
+	if _, err := rand.Read(buf); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+func bulkRowError(row BulkUserRow, field, message string) Models.BulkJobError {
+	identifier := strings.TrimSpace(row.Username)
+	if identifier == "" {
+		identifier = strings.TrimSpace(row.Email)
+	}
+	return Models.BulkJobError{Row: row.Row, Identifier: identifier, Field: field, Message: message}
+}
+
+// runUserUpdate 执行批量角色分配或启用/禁用任务
+func (s *BulkOperationService) runUserUpdate(job *Models.BulkJob) error {
+	var params bulkUserUpdate
+	if err := json.Unmarshal([]byte(job.Payload), &params); err != nil {
+		return fmt.Errorf("解析任务数据失败: %v", err)
+	}
+	job.Total = len(params.UserIDs)
+
+	column, value := "role", interface{}(params.Role)
+	if job.Type == Models.BulkJobUserStatus {
+		column, value = "status", params.Status
+	}
+
+	// 角色写在token中，禁用的用户不能继续使用已签发的token，事务提交后统一撤销
+	var revoke []uint
+	handle := func(tx *gorm.DB, index int) []Models.BulkJobError {
+		id := params.UserIDs[index]
+		fail := func(message string) []Models.BulkJobError {
+			return []Models.BulkJobError{{Row: index + 1, Identifier: strconv.FormatUint(uint64(id), 10), Field: "user_id", Message: message}}
+		}
+
+		if id == job.CreatedBy {
+			return fail("不能修改当前操作者自己的账号")
+		}
+		var user Models.User
+		if err := tx.Select("id", "role", "status").First(&user, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fail("用户不存在")
+			}
+			return fail("查询用户失败: " + err.Error())
+		}
+		if (column == "role" && user.Role == params.Role) || (column == "status" && user.Status == params.Status) {
+			return nil
+		}
+
+		if err := tx.Model(&Models.User{}).Where("id = ?", id).Update(column, value).Error; err != nil {
+			return fail("更新用户失败: " + err.Error())
+		}
+		if column == "role" || params.Status == 0 {
+			revoke = append(revoke, id)
+		}
+		return nil
+	}
+
+	return s.runRows(job, handle, func() {
+		s.revokeTokens(revoke)
+		revoke = revoke[:0]
+	})
+}
+
+// runUserDelete 执行批量删除用户任务
+func (s *BulkOperationService) runUserDelete(job *Models.BulkJob) error {
+	var params bulkUserUpdate
+	if err := json.Unmarshal([]byte(job.Payload), &params); err != nil {
+		return fmt.Errorf("解析任务数据失败: %v", err)
+	}
+	job.Total = len(params.UserIDs)
+
+	deletion := DefaultDeletionService(s.db)
+	var revoke []uint
+	handle := func(tx *gorm.DB, index int) []Models.BulkJobError {
+		id := params.UserIDs[index]
+		fail := func(message string) []Models.BulkJobError {
+			return []Models.BulkJobError{{Row: index + 1, Identifier: strconv.FormatUint(uint64(id), 10), Field: "user_id", Message: message}}
+		}
+
+		if id == job.CreatedBy {
+			return fail("不能删除当前操作者自己的账号")
+		}
+		var user Models.User
+		if err := tx.Select("id", "role").First(&user, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fail("用户不存在")
+			}
+			return fail("查询用户失败: " + err.Error())
+		}
+		if user.Role == "admin" {
+			return fail("不能删除管理员账号")
+		}
+
+		// 删除用户和关联数据涉及多条语句，失败时只回滚该用户的修改
+		if err := tx.Transaction(func(tx *gorm.DB) error {
+			return deletion.DeleteTx(tx, TrashUsers, id)
+		}); err != nil {
+			return fail("删除用户失败: " + err.Error())
+		}
+		revoke = append(revoke, id)
+		return nil
+	}
+
+	return s.runRows(job, handle, func() {
+		s.revokeTokens(revoke)
+		revoke = revoke[:0]
+	})
+}
+
+// revokeTokens 撤销用户已签发的token
+func (s *BulkOperationService) revokeTokens(userIDs []uint) {
+	s.mu.Lock()
+	tokens := s.tokens
+	s.mu.Unlock()
+	if tokens == nil {
+		return
+	}
+	for _, id := range userIDs {
+		if err := tokens.RevokeUserTokens(id, tokenLifetime()); err != nil {
+			log.Printf("撤销用户token失败: user_id=%d, error=%v", id, err)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// CSP违规报告安全事件
+const (
+	CSPViolationEventType  = "csp_violation" // 安全事件类型
+	CSPViolationEventLevel = "low"           // 安全事件级别
+
+	// maxCSPReportsPerRequest 每个请求最多保存的报告数，Reporting API 可以批量上报
+	maxCSPReportsPerRequest = 20
+)
+
+// ErrInvalidCSPReport CSP违规报告格式无效
+var ErrInvalidCSPReport = errors.New("CSP违规报告格式无效")
+
+// CSPViolationReport CSP违规报告，兼容 report-uri 和 Reporting API 两种格式
+type CSPViolationReport struct {
+	DocumentURI        string `json:"document_uri"`
+	Referrer           string `json:"referrer,omitempty"`
+	BlockedURI         string `json:"blocked_uri"`
+	ViolatedDirective  string `json:"violated_directive,omitempty"`
+	EffectiveDirective string `json:"effective_directive"`
+	OriginalPolicy     string `json:"original_policy,omitempty"`
+	Disposition        string `json:"disposition,omitempty"` // enforce 或 report
+	SourceFile         string `json:"source_file,omitempty"`
+	LineNumber         int    `json:"line_number,omitempty"`
+	ColumnNumber       int    `json:"column_number,omitempty"`
+	StatusCode         int    `json:"status_code,omitempty"`
+	ScriptSample       string `json:"script_sample,omitempty"`
+}
+
+// legacyCSPReport report-uri 指令上报的格式，Content-Type 为 application/csp-report
+type legacyCSPReport struct {
+	Report struct {
+		DocumentURI        string `json:"document-uri"`
+		Referrer           string `json:"referrer"`
+		BlockedURI         string `json:"blocked-uri"`
+		ViolatedDirective  string `json:"violated-directive"`
+		EffectiveDirective string `json:"effective-directive"`
+		OriginalPolicy     string `json:"original-policy"`
+		Disposition        string `json:"disposition"`
+		SourceFile         string `json:"source-file"`
+		LineNumber         int    `json:"line-number"`
+		ColumnNumber       int    `json:"column-number"`
+		StatusCode         int    `json:"status-code"`
+		ScriptSample       string `json:"script-sample"`
+	} `json:"csp-report"`
+}
+
+// reportingAPIReport Reporting API 上报的格式，Content-Type 为 application/reports+json
+type reportingAPIReport struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+	Body struct {
+		DocumentURL        string `json:"documentURL"`
+		Referrer           string `json:"referrer"`
+		BlockedURL         string `json:"blockedURL"`
+		EffectiveDirective string `json:"effectiveDirective"`
+		OriginalPolicy     string `json:"originalPolicy"`
+		Disposition        string `json:"disposition"`
+		SourceFile         string `json:"sourceFile"`
+		LineNumber         int    `json:"lineNumber"`
+		ColumnNumber       int    `json:"columnNumber"`
+		StatusCode         int    `json:"statusCode"`
+		Sample             string `json:"sample"`
+	} `json:"body"`
+}
+
+// ParseCSPReports 解析CSP违规报告
+// 请求体为数组时按 Reporting API 解析，忽略 type 不是 csp-violation 的报告；为对象时按 report-uri 格式解析
+func ParseCSPReports(body []byte) ([]CSPViolationReport, error) {
+	trimmed := strings.TrimSpace(string(body))
+	if strings.HasPrefix(trimmed, "[") {
+		var items []reportingAPIReport
+		if err := json.Unmarshal(body, &items); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCSPReport, err)
+		}
+		var reports []CSPViolationReport
+		for _, item := range items {
+			if item.Type != "csp-violation" {
+				continue
+			}
+			documentURI := item.Body.DocumentURL
+			if documentURI == "" {
+				documentURI = item.URL
+			}
+			reports = append(reports, CSPViolationReport{
+				DocumentURI:        documentURI,
+				Referrer:           item.Body.Referrer,
+				BlockedURI:         item.Body.BlockedURL,
+				EffectiveDirective: item.Body.EffectiveDirective,
+				OriginalPolicy:     item.Body.OriginalPolicy,
+				Disposition:        item.Body.Disposition,
+				SourceFile:         item.Body.SourceFile,
+				LineNumber:         item.Body.LineNumber,
+				ColumnNumber:       item.Body.ColumnNumber,
+				StatusCode:         item.Body.StatusCode,
+				ScriptSample:       item.Body.Sample,
+			})
+		}
+		return reports, nil
+	}
+
+	var legacy legacyCSPReport
+	if err := json.Unmarshal(body, &legacy); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCSPReport, err)
+	}
+	r := legacy.Report
+	if r.DocumentURI == "" && r.ViolatedDirective == "" && r.EffectiveDirective == "" {
+		return nil, fmt.Errorf("%w: 缺少 csp-report", ErrInvalidCSPReport)
+	}
+	effective := r.EffectiveDirective
+	if effective == "" {
+		// 旧版浏览器只上报 violated-directive，如 "script-src 'self'"
+		effective = strings.Fields(r.ViolatedDirective + " ")[0]
+	}
+	return []CSPViolationReport{{
+		DocumentURI:        r.DocumentURI,
+		Referrer:           r.Referrer,
+		BlockedURI:         r.BlockedURI,
+		ViolatedDirective:  r.ViolatedDirective,
+		EffectiveDirective: effective,
+		OriginalPolicy:     r.OriginalPolicy,
+		Disposition:        r.Disposition,
+		SourceFile:         r.SourceFile,
+		LineNumber:         r.LineNumber,
+		ColumnNumber:       r.ColumnNumber,
+		StatusCode:         r.StatusCode,
+		ScriptSample:       r.ScriptSample,
+	}}, nil
+}
+
+// CSPReportService CSP违规报告服务
+// 功能说明：
+// 1. 将浏览器上报的CSP违规报告保存为 csp_violation 类型的安全事件
+// 2. 资源为被阻止的地址，操作为违反的指令，详细信息为报告JSON，可以在安全事件列表中按类型查询
+// 3. 报告由浏览器匿名上报，不关联用户；每个请求最多保存20条报告
+type CSPReportService struct {
+	db *gorm.DB
+}
+
+// NewCSPReportService 创建CSP违规报告服务
+func NewCSPReportService(db *gorm.DB) *CSPReportService {
+	return &CSPReportService{db: db}
+}
+
+// Record 保存CSP违规报告，返回保存的报告数
+func (s *CSPReportService) Record(reports []CSPViolationReport, ipAddress, userAgent string) (int, error) {
+	if len(reports) > maxCSPReportsPerRequest {
+		reports = reports[:maxCSPReportsPerRequest]
+	}
+	if len(reports) == 0 {
+		return 0, nil
+	}
+
+	events := make([]Models.SecurityEvent, 0, len(reports))
+	for _, report := range reports {
+		details, err := json.Marshal(report)
+		if err != nil {
+			return 0, err
+		}
+		events = append(events, Models.SecurityEvent{
+			EventType:  CSPViolationEventType,
+			EventLevel: CSPViolationEventLevel,
+			IPAddress:  ipAddress,
+			UserAgent:  userAgent,
+			Resource:   truncateUTF8(report.BlockedURI, 255),
+			Action:     truncateUTF8(report.EffectiveDirective, 100),
+			Details:    string(details),
+		})
+	}
+	if err := s.db.Create(&events).Error; err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
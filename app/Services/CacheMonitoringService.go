@@ -0,0 +1,551 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 缓存告警类型
+const (
+	CacheAlertHitRateLow      = "cache_hit_rate_low"
+	CacheAlertMemoryHigh      = "cache_memory_high"
+	CacheAlertConnectionsHigh = "cache_connections_high"
+	CacheAlertEvictionsHigh   = "cache_evictions_high"
+	CacheAlertExpiredKeysHigh = "cache_expired_keys_high"
+)
+
+// TTLBuckets TTL分布的统计区间，按从短到长排列
+var TTLBuckets = []string{"persistent", "lt_1m", "1m_1h", "1h_1d", "gt_1d"}
+
+// CacheNodeMetrics 单个Redis节点指标
+//
+// 命中、未命中、驱逐和过期键数量为 INFO 中的累计值。
+type CacheNodeMetrics struct {
+	Addr             string  `json:"addr"`
+	Role             string  `json:"role"`
+	UsedMemory       int64   `json:"used_memory"`
+	MaxMemory        int64   `json:"max_memory"` // 未设置 maxmemory 时为系统内存
+	MemoryUsage      float64 `json:"memory_usage"`
+	ConnectedClients int64   `json:"connected_clients"`
+	KeyspaceHits     int64   `json:"keyspace_hits"`
+	KeyspaceMisses   int64   `json:"keyspace_misses"`
+	EvictedKeys      int64   `json:"evicted_keys"`
+	ExpiredKeys      int64   `json:"expired_keys"`
+	Keys             int64   `json:"keys"`
+	Expires          int64   `json:"expires"`
+}
+
+// CacheMetrics 缓存指标汇总
+//
+// 命中率、驱逐和过期键数量为两次采集之间的增量；首次采集时命中率按累计值计算，
+// 驱逐和过期键增量为0。集群模式下汇总所有主节点。
+type CacheMetrics struct {
+	Mode             string             `json:"mode"`
+	Nodes            []CacheNodeMetrics `json:"nodes"`
+	HitRate          float64            `json:"hit_rate"`
+	KeyspaceHits     int64              `json:"keyspace_hits"`
+	KeyspaceMisses   int64              `json:"keyspace_misses"`
+	UsedMemory       int64              `json:"used_memory"`
+	MaxMemory        int64              `json:"max_memory"`
+	ConnectedClients int64              `json:"connected_clients"`
+	EvictedKeys      int64              `json:"evicted_keys"`
+	ExpiredKeys      int64              `json:"expired_keys"`
+	Keys             int64              `json:"keys"`
+	Expires          int64              `json:"expires"`
+	TTLDistribution  map[string]int64   `json:"ttl_distribution"`
+	SampledKeys      int64              `json:"sampled_keys"`
+	CollectedAt      time.Time          `json:"collected_at"`
+}
+
+// CacheAlert 缓存告警
+type CacheAlert struct {
+	Type        string    `json:"type"`
+	Node        string    `json:"node,omitempty"`
+	Message     string    `json:"message"`
+	Value       float64   `json:"value"`
+	Threshold   float64   `json:"threshold"`
+	TriggeredAt time.Time `json:"triggered_at"`
+}
+
+// CacheMonitoringService 缓存监控服务
+// 功能说明：
+// 1. 定期读取Redis INFO，统计命中率、内存、驱逐、过期键和连接数
+// 2. 抽样读取键的TTL，统计TTL分布
+// 3. 按 CacheMonitoring 配置的阈值检查指标，超过阈值时记录告警，同一告警有冷却时间
+// 4. 支持单机、集群（采集所有主节点）和哨兵（采集当前主节点）三种部署模式
+type CacheMonitoringService struct {
+	config      *Config.MonitoringConfig
+	redisConfig *Config.RedisConfig
+	client      redis.UniversalClient
+
+	mu         sync.RWMutex
+	previous   map[string]CacheNodeMetrics
+	latest     *CacheMetrics
+	alerts     []CacheAlert
+	lastAlerts map[string]time.Time
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	isRunning bool
+}
+
+// NewRedisUniversalClient 按部署模式创建Redis客户端，客户端带有故障注入和请求调试钩子
+func NewRedisUniversalClient(config *Config.RedisConfig) redis.UniversalClient {
+	var client redis.UniversalClient
+	switch config.GetMode() {
+	case Config.RedisModeCluster:
+		client = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:    config.GetAddrs(),
+			Password: config.Password,
+		})
+	case Config.RedisModeSentinel:
+		client = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       config.MasterName,
+			SentinelAddrs:    config.GetAddrs(),
+			SentinelPassword: config.SentinelPassword,
+			Password:         config.Password,
+			DB:               config.GetDB(),
+		})
+	default:
+		client = redis.NewClient(&redis.Options{
+			Addr:     config.GetAddr(),
+			Password: config.Password,
+			DB:       config.GetDB(),
+		})
+	}
+	client.AddHook(FaultInjectionRedisHook{})
+	client.AddHook(DebugRedisHook{})
+	return client
+}
+
+// NewCacheMonitoringService 创建缓存监控服务
+//
+// config 为 nil 时使用全局监控配置，redisConfig 为 nil 时使用全局Redis配置，
+// 全局配置未加载时使用默认值。Redis客户端在首次采集时才建立连接。
+func NewCacheMonitoringService(config *Config.MonitoringConfig, redisConfig *Config.RedisConfig) *CacheMonitoringService {
+	global := Config.GetConfig()
+	if config == nil {
+		if global != nil {
+			config = &global.Monitoring
+		} else {
+			config = &Config.MonitoringConfig{}
+			config.SetDefaults()
+		}
+	}
+	if redisConfig == nil {
+		if global != nil {
+			redisConfig = &global.Redis
+		} else {
+			redisConfig = &Config.RedisConfig{Host: "localhost", Port: 6379}
+		}
+	}
+
+	return &CacheMonitoringService{
+		config:      config,
+		redisConfig: redisConfig,
+		client:      NewRedisUniversalClient(redisConfig),
+		previous:    make(map[string]CacheNodeMetrics),
+		lastAlerts:  make(map[string]time.Time),
+	}
+}
+
+// Start 启动定期采集
+//
+// 未启用缓存监控时不启动后台协程。
+func (s *CacheMonitoringService) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isRunning {
+		return fmt.Errorf("cache monitoring service is already running")
+	}
+	if !s.config.CacheMonitoring.Enabled || s.config.CacheMonitoring.CheckInterval <= 0 {
+		return nil
+	}
+
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	go s.checkLoop(s.ctx)
+
+	s.isRunning = true
+	return nil
+}
+
+// Stop 停止定期采集
+func (s *CacheMonitoringService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isRunning {
+		return
+	}
+	s.cancel()
+	s.isRunning = false
+}
+
+// checkLoop 定期采集并检查阈值
+func (s *CacheMonitoringService) checkLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.config.CacheMonitoring.CheckInterval)
+	defer ticker.Stop()
+
+	s.check(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.check(ctx)
+		}
+	}
+}
+
+// check 执行一次采集，失败时只记录日志
+func (s *CacheMonitoringService) check(ctx context.Context) {
+	collectCtx, cancel := context.WithTimeout(ctx, s.config.CacheMonitoring.CheckInterval)
+	defer cancel()
+
+	if _, _, err := s.Check(collectCtx); err != nil {
+		log.Printf("缓存指标采集失败: %v", err)
+	}
+}
+
+// Check 采集缓存指标并检查阈值，返回本次触发的告警
+func (s *CacheMonitoringService) Check(ctx context.Context) (*CacheMetrics, []CacheAlert, error) {
+	metrics, err := s.Collect(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return metrics, s.Evaluate(metrics), nil
+}
+
+// Collect 采集所有节点的INFO和TTL分布
+func (s *CacheMonitoringService) Collect(ctx context.Context) (*CacheMetrics, error) {
+	var mu sync.Mutex
+	var nodes []CacheNodeMetrics
+	ttl := make(map[string]int64, len(TTLBuckets))
+
+	err := s.forEachNode(ctx, func(ctx context.Context, client *redis.Client) error {
+		info, err := client.Info(ctx).Result()
+		if err != nil {
+			return err
+		}
+		node := ParseCacheNodeMetrics(s.nodeAddr(client), info)
+
+		sampled, err := s.sampleTTL(ctx, client)
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		nodes = append(nodes, node)
+		for bucket, count := range sampled {
+			ttl[bucket] += count
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("采集Redis指标失败: %v", err)
+	}
+
+	return s.Record(nodes, ttl), nil
+}
+
+// forEachNode 对需要采集的每个节点执行 fn
+// 集群模式遍历所有主节点，单机和哨兵模式只有一个节点
+func (s *CacheMonitoringService) forEachNode(ctx context.Context, fn func(ctx context.Context, client *redis.Client) error) error {
+	switch client := s.client.(type) {
+	case *redis.ClusterClient:
+		return client.ForEachMaster(ctx, fn)
+	case *redis.Client:
+		return fn(ctx, client)
+	default:
+		return fmt.Errorf("不支持的Redis客户端类型: %T", s.client)
+	}
+}
+
+// nodeAddr 节点名称，哨兵模式的客户端没有固定地址，使用主节点名称
+func (s *CacheMonitoringService) nodeAddr(client *redis.Client) string {
+	if s.redisConfig.GetMode() == Config.RedisModeSentinel {
+		return s.redisConfig.MasterName
+	}
+	return client.Options().Addr
+}
+
+// sampleTTL 扫描节点上的部分键并统计TTL分布
+func (s *CacheMonitoringService) sampleTTL(ctx context.Context, client *redis.Client) (map[string]int64, error) {
+	limit := s.config.CacheMonitoring.KeySampleSize
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	var keys []string
+	var cursor uint64
+	for len(keys) < limit {
+		batch, next, err := client.Scan(ctx, cursor, "", int64(limit-len(keys))).Result()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, batch...)
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	pipe := client.Pipeline()
+	cmds := make([]*redis.DurationCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.TTL(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	distribution := make(map[string]int64, len(TTLBuckets))
+	for _, cmd := range cmds {
+		ttl, err := cmd.Result()
+		// 扫描后已过期或被删除的键返回 -2
+		if err != nil || ttl == -2 {
+			continue
+		}
+		distribution[TTLBucket(ttl)]++
+	}
+	return distribution, nil
+}
+
+// TTLBucket 返回TTL所属的统计区间，没有过期时间的键返回 persistent
+func TTLBucket(ttl time.Duration) string {
+	switch {
+	case ttl < 0:
+		return "persistent"
+	case ttl < time.Minute:
+		return "lt_1m"
+	case ttl < time.Hour:
+		return "1m_1h"
+	case ttl < 24*time.Hour:
+		return "1h_1d"
+	default:
+		return "gt_1d"
+	}
+}
+
+// ParseRedisInfo 解析 INFO 命令输出为键值对，忽略分段标题和空行
+func ParseRedisInfo(info string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(info, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+// ParseCacheNodeMetrics 从 INFO 命令输出解析节点指标
+func ParseCacheNodeMetrics(addr, info string) CacheNodeMetrics {
+	fields := ParseRedisInfo(info)
+	integer := func(key string) int64 {
+		value, _ := strconv.ParseInt(fields[key], 10, 64)
+		return value
+	}
+
+	node := CacheNodeMetrics{
+		Addr:             addr,
+		Role:             fields["role"],
+		UsedMemory:       integer("used_memory"),
+		MaxMemory:        integer("maxmemory"),
+		ConnectedClients: integer("connected_clients"),
+		KeyspaceHits:     integer("keyspace_hits"),
+		KeyspaceMisses:   integer("keyspace_misses"),
+		EvictedKeys:      integer("evicted_keys"),
+		ExpiredKeys:      integer("expired_keys"),
+	}
+	// 未设置 maxmemory 时按系统内存计算使用率
+	if node.MaxMemory == 0 {
+		node.MaxMemory = integer("total_system_memory")
+	}
+	if node.MaxMemory > 0 {
+		node.MemoryUsage = float64(node.UsedMemory) / float64(node.MaxMemory) * 100
+	}
+
+	// Keyspace 段格式：db0:keys=1,expires=0,avg_ttl=0
+	for key, value := range fields {
+		if !strings.HasPrefix(key, "db") {
+			continue
+		}
+		if _, err := strconv.Atoi(key[2:]); err != nil {
+			continue
+		}
+		for _, pair := range strings.Split(value, ",") {
+			name, count, _ := strings.Cut(pair, "=")
+			n, _ := strconv.ParseInt(count, 10, 64)
+			switch name {
+			case "keys":
+				node.Keys += n
+			case "expires":
+				node.Expires += n
+			}
+		}
+	}
+	return node
+}
+
+// Record 记录一次采集结果，按上次采集的累计值计算增量并汇总
+func (s *CacheMonitoringService) Record(nodes []CacheNodeMetrics, ttl map[string]int64) *CacheMetrics {
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Addr < nodes[j].Addr })
+
+	metrics := &CacheMetrics{
+		Mode:            s.redisConfig.GetMode(),
+		Nodes:           nodes,
+		TTLDistribution: make(map[string]int64, len(TTLBuckets)),
+		CollectedAt:     time.Now(),
+	}
+	for _, bucket := range TTLBuckets {
+		metrics.TTLDistribution[bucket] = ttl[bucket]
+		metrics.SampledKeys += ttl[bucket]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, node := range nodes {
+		hits, misses := node.KeyspaceHits, node.KeyspaceMisses
+		if prev, ok := s.previous[node.Addr]; ok {
+			hits = counterDelta(prev.KeyspaceHits, node.KeyspaceHits)
+			misses = counterDelta(prev.KeyspaceMisses, node.KeyspaceMisses)
+			metrics.EvictedKeys += counterDelta(prev.EvictedKeys, node.EvictedKeys)
+			metrics.ExpiredKeys += counterDelta(prev.ExpiredKeys, node.ExpiredKeys)
+		}
+		metrics.KeyspaceHits += hits
+		metrics.KeyspaceMisses += misses
+		metrics.UsedMemory += node.UsedMemory
+		metrics.MaxMemory += node.MaxMemory
+		metrics.ConnectedClients += node.ConnectedClients
+		metrics.Keys += node.Keys
+		metrics.Expires += node.Expires
+		s.previous[node.Addr] = node
+	}
+	if total := metrics.KeyspaceHits + metrics.KeyspaceMisses; total > 0 {
+		metrics.HitRate = float64(metrics.KeyspaceHits) / float64(total) * 100
+	}
+
+	s.latest = metrics
+	return metrics
+}
+
+// counterDelta 累计计数器的增量，节点重启导致计数归零时返回当前值
+func counterDelta(previous, current int64) int64 {
+	if current < previous {
+		return current
+	}
+	return current - previous
+}
+
+// Evaluate 按配置的阈值检查指标，返回本次触发的告警
+//
+// 内存使用率和连接数按节点检查，命中率、驱逐和过期键按检查间隔内的汇总增量检查；
+// 阈值为0时不检查。冷却时间内的重复告警会被忽略。
+func (s *CacheMonitoringService) Evaluate(metrics *CacheMetrics) []CacheAlert {
+	thresholds := s.config.CacheMonitoring
+	var candidates []CacheAlert
+
+	if thresholds.HitRateThreshold > 0 && metrics.KeyspaceHits+metrics.KeyspaceMisses > 0 && metrics.HitRate < thresholds.HitRateThreshold {
+		candidates = append(candidates, CacheAlert{
+			Type:      CacheAlertHitRateLow,
+			Message:   fmt.Sprintf("缓存命中率过低: %.2f%%", metrics.HitRate),
+			Value:     metrics.HitRate,
+			Threshold: thresholds.HitRateThreshold,
+		})
+	}
+	for _, node := range metrics.Nodes {
+		if thresholds.MemoryUsageThreshold > 0 && node.MemoryUsage > thresholds.MemoryUsageThreshold {
+			candidates = append(candidates, CacheAlert{
+				Type:      CacheAlertMemoryHigh,
+				Node:      node.Addr,
+				Message:   fmt.Sprintf("Redis节点 %s 内存使用率过高: %.2f%%", node.Addr, node.MemoryUsage),
+				Value:     node.MemoryUsage,
+				Threshold: thresholds.MemoryUsageThreshold,
+			})
+		}
+		if thresholds.ConnectionThreshold > 0 && node.ConnectedClients > int64(thresholds.ConnectionThreshold) {
+			candidates = append(candidates, CacheAlert{
+				Type:      CacheAlertConnectionsHigh,
+				Node:      node.Addr,
+				Message:   fmt.Sprintf("Redis节点 %s 连接数过多: %d", node.Addr, node.ConnectedClients),
+				Value:     float64(node.ConnectedClients),
+				Threshold: float64(thresholds.ConnectionThreshold),
+			})
+		}
+	}
+	if thresholds.EvictionThreshold > 0 && metrics.EvictedKeys > int64(thresholds.EvictionThreshold) {
+		candidates = append(candidates, CacheAlert{
+			Type:      CacheAlertEvictionsHigh,
+			Message:   fmt.Sprintf("缓存驱逐键数量过多: %d", metrics.EvictedKeys),
+			Value:     float64(metrics.EvictedKeys),
+			Threshold: float64(thresholds.EvictionThreshold),
+		})
+	}
+	if thresholds.ExpiredKeysThreshold > 0 && metrics.ExpiredKeys > int64(thresholds.ExpiredKeysThreshold) {
+		candidates = append(candidates, CacheAlert{
+			Type:      CacheAlertExpiredKeysHigh,
+			Message:   fmt.Sprintf("缓存过期键数量过多: %d", metrics.ExpiredKeys),
+			Value:     float64(metrics.ExpiredKeys),
+			Threshold: float64(thresholds.ExpiredKeysThreshold),
+		})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var triggered []CacheAlert
+	for i := range candidates {
+		alert := &candidates[i]
+		alert.TriggeredAt = metrics.CollectedAt
+		key := alert.Type + "|" + alert.Node
+		if last, ok := s.lastAlerts[key]; ok && metrics.CollectedAt.Sub(last) < thresholds.AlertCooldown {
+			continue
+		}
+		s.lastAlerts[key] = metrics.CollectedAt
+		triggered = append(triggered, *alert)
+		log.Printf("缓存告警: %s - %s", alert.Type, alert.Message)
+	}
+	s.alerts = candidates
+	return triggered
+}
+
+// GetMetrics 最近一次采集的指标，尚未采集时返回 nil
+func (s *CacheMonitoringService) GetMetrics() *CacheMetrics {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.latest
+}
+
+// GetAlerts 最近一次检查时超过阈值的告警（不受冷却时间影响）
+func (s *CacheMonitoringService) GetAlerts() []CacheAlert {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]CacheAlert(nil), s.alerts...)
+}
+
+// Close 停止采集并关闭Redis客户端
+func (s *CacheMonitoringService) Close() error {
+	s.Stop()
+	return s.client.Close()
+}
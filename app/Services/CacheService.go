@@ -2,8 +2,10 @@ package Services
 
 import (
 	"cloud-platform-api/app/Storage"
+	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 )
@@ -14,15 +16,20 @@ import (
 // 2. 支持TTL（生存时间）管理
 // 3. 提供缓存统计和监控
 // 4. 支持缓存预热和清理
+// 5. 支持切换到Redis后端，切换后读写都经过Redis
 type CacheService struct {
 	BaseService
 	storageManager *Storage.StorageManager
 	cache          map[string]*CacheItem
+	redis          *RedisService // Redis后端，为 nil 时使用内存缓存
 	mutex          sync.RWMutex
 	stats          *CacheStats
 	config         *CacheConfig
 }
 
+// cacheRedisKeyPrefix Redis后端的缓存键前缀，清空缓存和列出键时只处理该前缀的键
+const cacheRedisKeyPrefix = "cache:"
+
 // CacheItem 缓存项
 type CacheItem struct {
 	Value       interface{} `json:"value"`
@@ -73,22 +80,78 @@ func NewCacheService(storageManager *Storage.StorageManager) *CacheService {
 	return service
 }
 
-// Set 设置缓存
-func (c *CacheService) Set(key string, value interface{}, ttl ...time.Duration) error {
+// UseRedis 切换缓存后端
+// 功能说明：
+// 1. redis 不为 nil 时之后的读写都经过Redis，值使用JSON序列化
+// 2. redis 为 nil 时切回内存缓存
+// 3. 切换时不迁移已有缓存项，切换到Redis时清空内存缓存
+func (c *CacheService) UseRedis(redis *RedisService) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	// 检查缓存大小限制
-	if len(c.cache) >= c.config.MaxSize {
-		c.evictOldest()
+	c.redis = redis
+	if redis != nil {
+		c.cache = make(map[string]*CacheItem)
+	}
+}
+
+// Backend 获取当前缓存后端，redis 或 memory
+func (c *CacheService) Backend() string {
+	if c.redisBackend() != nil {
+		return "redis"
 	}
+	return "memory"
+}
 
+// redisBackend 获取Redis后端，未切换到Redis时返回 nil
+func (c *CacheService) redisBackend() *RedisService {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.redis
+}
+
+// recordAccess 记录一次缓存命中或未命中
+func (c *CacheService) recordAccess(hit bool) {
+	if !c.config.EnableStats {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if hit {
+		c.stats.HitCount++
+	} else {
+		c.stats.MissCount++
+	}
+}
+
+// Set 设置缓存
+func (c *CacheService) Set(key string, value interface{}, ttl ...time.Duration) error {
 	// 确定TTL
 	duration := c.config.DefaultTTL
 	if len(ttl) > 0 {
 		duration = ttl[0]
 	}
 
+	if redis := c.redisBackend(); redis != nil {
+		if err := redis.Set(cacheRedisKeyPrefix+key, value, duration); err != nil {
+			return err
+		}
+		if c.config.EnableStats {
+			c.mutex.Lock()
+			c.stats.SetCount++
+			c.mutex.Unlock()
+		}
+		return nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// 检查缓存大小限制
+	if len(c.cache) >= c.config.MaxSize {
+		c.evictOldest()
+	}
+
 	// 创建缓存项
 	item := &CacheItem{
 		Value:       value,
@@ -109,7 +172,18 @@ func (c *CacheService) Set(key string, value interface{}, ttl ...time.Duration)
 }
 
 // Get 获取缓存
+// Redis后端的值经JSON反序列化，返回 map、切片、float64 等通用类型
 func (c *CacheService) Get(key string) (interface{}, bool) {
+	if redis := c.redisBackend(); redis != nil {
+		var value interface{}
+		if err := redis.Get(cacheRedisKeyPrefix+key, &value); err != nil {
+			c.recordAccess(false)
+			return nil, false
+		}
+		c.recordAccess(true)
+		return value, true
+	}
+
 	c.mutex.RLock()
 	item, exists := c.cache[key]
 	c.mutex.RUnlock()
@@ -147,6 +221,15 @@ func (c *CacheService) Get(key string) (interface{}, bool) {
 
 // Delete 删除缓存
 func (c *CacheService) Delete(key string) {
+	if redis := c.redisBackend(); redis != nil {
+		if err := redis.Delete(cacheRedisKeyPrefix + key); err == nil && c.config.EnableStats {
+			c.mutex.Lock()
+			c.stats.DeleteCount++
+			c.mutex.Unlock()
+		}
+		return
+	}
+
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
@@ -160,7 +243,21 @@ func (c *CacheService) Delete(key string) {
 }
 
 // Clear 清空所有缓存
+// Redis后端只删除缓存键前缀下的键，不影响同一数据库中的其他数据
 func (c *CacheService) Clear() {
+	if redis := c.redisBackend(); redis != nil {
+		ctx := context.Background()
+		keys, err := redis.Keys(ctx, cacheRedisKeyPrefix+"*")
+		if err != nil {
+			c.storageManager.LogWarning("清空Redis缓存失败", map[string]interface{}{"error": err.Error()})
+			return
+		}
+		for _, key := range keys {
+			redis.Del(ctx, key)
+		}
+		return
+	}
+
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
@@ -172,12 +269,14 @@ func (c *CacheService) Clear() {
 
 // GetStats 获取缓存统计
 func (c *CacheService) GetStats() *CacheStats {
+	size := c.Size()
+
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
 
 	// 创建统计副本
 	stats := *c.stats
-	stats.TotalItems = int64(len(c.cache))
+	stats.TotalItems = int64(size)
 
 	return &stats
 }
@@ -254,6 +353,11 @@ func (c *CacheService) cleanup() {
 
 // SetWithJSON 使用JSON序列化设置缓存
 func (c *CacheService) SetWithJSON(key string, value interface{}, ttl ...time.Duration) error {
+	// Redis后端本身按JSON保存值
+	if c.redisBackend() != nil {
+		return c.Set(key, value, ttl...)
+	}
+
 	jsonData, err := json.Marshal(value)
 	if err != nil {
 		return fmt.Errorf("序列化失败: %v", err)
@@ -264,6 +368,15 @@ func (c *CacheService) SetWithJSON(key string, value interface{}, ttl ...time.Du
 
 // GetWithJSON 使用JSON反序列化获取缓存
 func (c *CacheService) GetWithJSON(key string, dest interface{}) error {
+	if redis := c.redisBackend(); redis != nil {
+		if err := redis.Get(cacheRedisKeyPrefix+key, dest); err != nil {
+			c.recordAccess(false)
+			return fmt.Errorf("缓存未找到: %s", key)
+		}
+		c.recordAccess(true)
+		return nil
+	}
+
 	value, exists := c.Get(key)
 	if !exists {
 		return fmt.Errorf("缓存未找到: %s", key)
@@ -279,6 +392,11 @@ func (c *CacheService) GetWithJSON(key string, dest interface{}) error {
 
 // Exists 检查缓存是否存在
 func (c *CacheService) Exists(key string) bool {
+	if redis := c.redisBackend(); redis != nil {
+		exists, err := redis.Exists(cacheRedisKeyPrefix + key)
+		return err == nil && exists
+	}
+
 	c.mutex.RLock()
 	item, exists := c.cache[key]
 	c.mutex.RUnlock()
@@ -298,6 +416,14 @@ func (c *CacheService) Exists(key string) bool {
 
 // GetTTL 获取剩余TTL
 func (c *CacheService) GetTTL(key string) time.Duration {
+	if redis := c.redisBackend(); redis != nil {
+		remaining, err := redis.TTL(cacheRedisKeyPrefix + key)
+		if err != nil || remaining <= 0 {
+			return 0
+		}
+		return remaining
+	}
+
 	c.mutex.RLock()
 	item, exists := c.cache[key]
 	c.mutex.RUnlock()
@@ -327,6 +453,17 @@ func (c *CacheService) SetConfig(config *CacheConfig) {
 
 // Keys 获取所有缓存键
 func (c *CacheService) Keys() []string {
+	if redis := c.redisBackend(); redis != nil {
+		keys, err := redis.Keys(context.Background(), cacheRedisKeyPrefix+"*")
+		if err != nil {
+			return []string{}
+		}
+		for i, key := range keys {
+			keys[i] = strings.TrimPrefix(key, cacheRedisKeyPrefix)
+		}
+		return keys
+	}
+
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
 
@@ -339,6 +476,10 @@ func (c *CacheService) Keys() []string {
 
 // Size 获取缓存项数量
 func (c *CacheService) Size() int {
+	if c.redisBackend() != nil {
+		return len(c.Keys())
+	}
+
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
 
@@ -350,6 +491,7 @@ func (c *CacheService) WarmCache() error {
 	// 这里可以添加缓存预热逻辑
 	// 例如：预加载常用数据、配置信息等
 	c.storageManager.LogInfo("缓存预热开始", map[string]interface{}{
+		"backend":    c.Backend(),
 		"cache_size": c.Size(),
 	})
 
@@ -370,6 +512,7 @@ func (c *CacheService) HealthCheck() map[string]interface{} {
 
 	health := map[string]interface{}{
 		"status":      "healthy",
+		"backend":     c.Backend(),
 		"hit_rate":    fmt.Sprintf("%.2f%%", hitRate*100),
 		"total_items": stats.TotalItems,
 		"hit_count":   stats.HitCount,
@@ -0,0 +1,452 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rdapDependency RDAP查询在出站HTTP客户端中的依赖名称
+const rdapDependency = "rdap"
+
+// CertificateInfo 证书检查结果
+type CertificateInfo struct {
+	Target        string    `json:"target"` // host:port
+	Subject       string    `json:"subject,omitempty"`
+	Issuer        string    `json:"issuer,omitempty"`
+	SANs          []string  `json:"sans,omitempty"` // 证书中的DNS名称和IP地址
+	SerialNumber  string    `json:"serial_number,omitempty"`
+	NotBefore     time.Time `json:"not_before,omitempty"`
+	NotAfter      time.Time `json:"not_after,omitempty"`
+	DaysRemaining float64   `json:"days_remaining"`
+	ChainValid    bool      `json:"chain_valid"`           // 证书链是否可信且与主机名匹配
+	ChainError    string    `json:"chain_error,omitempty"` // 证书链校验失败原因
+	Error         string    `json:"error,omitempty"`       // 连接或握手失败原因，此时没有证书信息
+	CheckedAt     time.Time `json:"checked_at"`
+}
+
+// DomainExpiryInfo 域名注册到期检查结果
+type DomainExpiryInfo struct {
+	Domain        string    `json:"domain"`
+	Registrar     string    `json:"registrar,omitempty"`
+	ExpiresAt     time.Time `json:"expires_at,omitempty"`
+	DaysRemaining float64   `json:"days_remaining"`
+	Error         string    `json:"error,omitempty"`
+	CheckedAt     time.Time `json:"checked_at"`
+}
+
+// CertificateExpiryCollector TLS证书和域名到期采集器
+// 功能说明：
+// 1. 连接各地址完成TLS握手，记录证书的颁发者、SAN和有效期，并按系统根证书校验证书链和主机名
+// 2. 通过RDAP查询域名的注册到期时间
+// 3. 指标：tls_certificate_expiry_days{target="..."}、tls_certificate_chain_valid{target="..."}、domain_expiry_days{domain="..."}、certificate_check_failures
+// 4. AlertRules 按告警天数为每个地址和域名生成告警规则，由监控核心的告警引擎评估
+// 5. 最近一次检查结果通过 Certificates、Domains 提供给证书列表接口
+type CertificateExpiryCollector struct {
+	targets    []string
+	domains    []string
+	thresholds []int
+	rdapURL    string
+	client     *OutboundHTTPClient
+	roots      *x509.CertPool
+
+	mu           sync.RWMutex
+	certificates map[string]CertificateInfo
+	domainInfos  map[string]DomainExpiryInfo
+}
+
+// NewCertificateExpiryCollector 创建证书和域名到期采集器
+// targets 格式为 host[:port]，默认端口443；thresholds 为告警天数
+func NewCertificateExpiryCollector(targets, domains []string, thresholds []int, rdapURL string) *CertificateExpiryCollector {
+	normalized := make([]string, 0, len(targets))
+	for _, target := range targets {
+		if _, _, err := net.SplitHostPort(target); err != nil {
+			target = net.JoinHostPort(strings.Trim(target, "[]"), "443")
+		}
+		normalized = append(normalized, target)
+	}
+	sorted := append([]int(nil), thresholds...)
+	sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+
+	return &CertificateExpiryCollector{
+		targets:      normalized,
+		domains:      domains,
+		thresholds:   sorted,
+		rdapURL:      rdapURL,
+		client:       GetOutboundHTTPClient(),
+		certificates: make(map[string]CertificateInfo),
+		domainInfos:  make(map[string]DomainExpiryInfo),
+	}
+}
+
+// NewCertificateExpiryCollectorFromConfig 按监控配置（MONITORING_COLLECTORS_CERTIFICATES_*）创建证书和域名到期采集器
+func NewCertificateExpiryCollectorFromConfig(config *Config.MonitoringConfig) (*CertificateExpiryCollector, error) {
+	certificates := config.Collectors.Certificates
+	thresholds, err := parseCertificateThresholds(certificates.Thresholds)
+	if err != nil {
+		return nil, err
+	}
+	return NewCertificateExpiryCollector(
+		splitNotificationList(certificates.Targets), splitNotificationList(certificates.Domains), thresholds, certificates.RDAPURL,
+	), nil
+}
+
+// parseCertificateThresholds 解析逗号分隔的告警天数
+func parseCertificateThresholds(raw string) ([]int, error) {
+	var thresholds []int
+	for _, item := range splitNotificationList(raw) {
+		days, err := strconv.Atoi(item)
+		if err != nil || days <= 0 {
+			return nil, fmt.Errorf("invalid certificate expiry threshold: %q", item)
+		}
+		thresholds = append(thresholds, days)
+	}
+	return thresholds, nil
+}
+
+// SetHTTPClient 设置RDAP查询使用的出站HTTP客户端
+func (c *CertificateExpiryCollector) SetHTTPClient(client *OutboundHTTPClient) {
+	c.client = client
+}
+
+// SetRootCAs 设置校验证书链使用的根证书，未设置时使用系统根证书
+func (c *CertificateExpiryCollector) SetRootCAs(roots *x509.CertPool) {
+	c.roots = roots
+}
+
+// Name 采集器名称
+func (c *CertificateExpiryCollector) Name() string {
+	return "certificates"
+}
+
+// Init 校验地址格式
+func (c *CertificateExpiryCollector) Init(ctx context.Context) error {
+	for _, target := range c.targets {
+		host, port, err := net.SplitHostPort(target)
+		if err != nil || host == "" || port == "" {
+			return fmt.Errorf("invalid certificate target: %q", target)
+		}
+	}
+	if len(c.domains) > 0 && c.rdapURL == "" {
+		return fmt.Errorf("RDAP URL is required for domain expiry checks")
+	}
+	return nil
+}
+
+// Collect 并发检查全部证书和域名，超时由 ctx 控制
+// 单个地址或域名检查失败只记录在检查结果中，全部失败时返回错误
+func (c *CertificateExpiryCollector) Collect(ctx context.Context) (map[string]float64, error) {
+	var wg sync.WaitGroup
+	certificates := make([]CertificateInfo, len(c.targets))
+	for i, target := range c.targets {
+		wg.Add(1)
+		go func(i int, target string) {
+			defer wg.Done()
+			certificates[i] = c.checkCertificate(ctx, target)
+		}(i, target)
+	}
+	domains := make([]DomainExpiryInfo, len(c.domains))
+	for i, domain := range c.domains {
+		wg.Add(1)
+		go func(i int, domain string) {
+			defer wg.Done()
+			domains[i] = c.checkDomain(ctx, domain)
+		}(i, domain)
+	}
+	wg.Wait()
+
+	metrics := make(map[string]float64)
+	var errs []error
+	for _, info := range certificates {
+		if info.Error != "" {
+			errs = append(errs, fmt.Errorf("%s: %s", info.Target, info.Error))
+			continue
+		}
+		labels := map[string]string{"target": info.Target}
+		metrics[ingestSeriesName("tls_certificate_expiry_days", labels)] = info.DaysRemaining
+		metrics[ingestSeriesName("tls_certificate_chain_valid", labels)] = boolMetric(info.ChainValid)
+	}
+	for _, info := range domains {
+		if info.Error != "" {
+			errs = append(errs, fmt.Errorf("%s: %s", info.Domain, info.Error))
+			continue
+		}
+		metrics[ingestSeriesName("domain_expiry_days", map[string]string{"domain": info.Domain})] = info.DaysRemaining
+	}
+	metrics["certificate_check_failures"] = float64(len(errs))
+
+	c.mu.Lock()
+	for _, info := range certificates {
+		c.certificates[info.Target] = info
+	}
+	for _, info := range domains {
+		c.domainInfos[info.Domain] = info
+	}
+	c.mu.Unlock()
+
+	if len(errs) > 0 && len(errs) == len(certificates)+len(domains) {
+		return nil, errors.Join(errs...)
+	}
+	return metrics, nil
+}
+
+// Certificates 最近一次证书检查结果，按剩余天数升序，检查失败的排在最前
+func (c *CertificateExpiryCollector) Certificates() []CertificateInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	certificates := make([]CertificateInfo, 0, len(c.certificates))
+	for _, info := range c.certificates {
+		certificates = append(certificates, info)
+	}
+	sort.Slice(certificates, func(i, j int) bool {
+		if (certificates[i].Error != "") != (certificates[j].Error != "") {
+			return certificates[i].Error != ""
+		}
+		if certificates[i].DaysRemaining != certificates[j].DaysRemaining {
+			return certificates[i].DaysRemaining < certificates[j].DaysRemaining
+		}
+		return certificates[i].Target < certificates[j].Target
+	})
+	return certificates
+}
+
+// Domains 最近一次域名检查结果，按剩余天数升序，检查失败的排在最前
+func (c *CertificateExpiryCollector) Domains() []DomainExpiryInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	domains := make([]DomainExpiryInfo, 0, len(c.domainInfos))
+	for _, info := range c.domainInfos {
+		domains = append(domains, info)
+	}
+	sort.Slice(domains, func(i, j int) bool {
+		if (domains[i].Error != "") != (domains[j].Error != "") {
+			return domains[i].Error != ""
+		}
+		if domains[i].DaysRemaining != domains[j].DaysRemaining {
+			return domains[i].DaysRemaining < domains[j].DaysRemaining
+		}
+		return domains[i].Domain < domains[j].Domain
+	})
+	return domains
+}
+
+// Thresholds 告警天数，降序
+func (c *CertificateExpiryCollector) Thresholds() []int {
+	return append([]int(nil), c.thresholds...)
+}
+
+// AlertRules 生成到期和证书链告警规则
+// 每个告警天数一条规则，天数越小级别越高：最大的一档为 warning，最小的一档为 critical，中间为 error
+func (c *CertificateExpiryCollector) AlertRules() []*AlertRule {
+	var rules []*AlertRule
+	for _, target := range c.targets {
+		labels := map[string]string{"target": target}
+		for i, days := range c.thresholds {
+			rules = append(rules, &AlertRule{
+				ID:        fmt.Sprintf("tls_certificate_expiry_%dd_%s", days, target),
+				Name:      fmt.Sprintf("证书 %s 将在 %d 天内过期", target, days),
+				Metric:    ingestSeriesName("tls_certificate_expiry_days", labels),
+				Condition: "<=",
+				Threshold: float64(days),
+				Level:     c.thresholdLevel(i),
+				Labels:    map[string]string{"target": target, "threshold": fmt.Sprintf("%dd", days)},
+				Enabled:   true,
+			})
+		}
+		rules = append(rules, &AlertRule{
+			ID:        "tls_certificate_chain_" + target,
+			Name:      fmt.Sprintf("证书 %s 证书链校验失败", target),
+			Metric:    ingestSeriesName("tls_certificate_chain_valid", labels),
+			Condition: "<",
+			Threshold: 1,
+			Level:     AlertLevelError,
+			Labels:    labels,
+			Enabled:   true,
+		})
+	}
+	for _, domain := range c.domains {
+		for i, days := range c.thresholds {
+			rules = append(rules, &AlertRule{
+				ID:        fmt.Sprintf("domain_expiry_%dd_%s", days, domain),
+				Name:      fmt.Sprintf("域名 %s 将在 %d 天内到期", domain, days),
+				Metric:    ingestSeriesName("domain_expiry_days", map[string]string{"domain": domain}),
+				Condition: "<=",
+				Threshold: float64(days),
+				Level:     c.thresholdLevel(i),
+				Labels:    map[string]string{"domain": domain, "threshold": fmt.Sprintf("%dd", days)},
+				Enabled:   true,
+			})
+		}
+	}
+	return rules
+}
+
+// thresholdLevel 第 i 档告警天数（降序）对应的告警级别
+func (c *CertificateExpiryCollector) thresholdLevel(i int) AlertLevel {
+	switch {
+	case i == len(c.thresholds)-1:
+		return AlertLevelCritical
+	case i == 0:
+		return AlertLevelWarning
+	default:
+		return AlertLevelError
+	}
+}
+
+// checkCertificate 握手获取证书并校验证书链
+func (c *CertificateExpiryCollector) checkCertificate(ctx context.Context, target string) CertificateInfo {
+	now := time.Now()
+	info := CertificateInfo{Target: target, CheckedAt: now}
+	host, _, _ := net.SplitHostPort(target)
+
+	// 握手时不校验证书，过期或不可信的证书也要读取到期时间，证书链在下面单独校验
+	dialer := &tls.Dialer{Config: &tls.Config{ServerName: host, InsecureSkipVerify: true}}
+	conn, err := dialer.DialContext(ctx, "tcp", target)
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	defer conn.Close()
+
+	peers := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(peers) == 0 {
+		info.Error = "server did not present a certificate"
+		return info
+	}
+	leaf := peers[0]
+	info.Subject = leaf.Subject.String()
+	info.Issuer = leaf.Issuer.String()
+	info.SerialNumber = leaf.SerialNumber.Text(16)
+	info.NotBefore = leaf.NotBefore
+	info.NotAfter = leaf.NotAfter
+	info.DaysRemaining = certificateDays(leaf.NotAfter.Sub(now))
+	info.SANs = append(info.SANs, leaf.DNSNames...)
+	for _, ip := range leaf.IPAddresses {
+		info.SANs = append(info.SANs, ip.String())
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range peers[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err = leaf.Verify(x509.VerifyOptions{
+		DNSName:       host,
+		Roots:         c.roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+	})
+	info.ChainValid = err == nil
+	if err != nil {
+		info.ChainError = err.Error()
+	}
+	return info
+}
+
+// rdapDomain RDAP域名查询响应中用到的字段
+type rdapDomain struct {
+	Events []struct {
+		EventAction string `json:"eventAction"`
+		EventDate   string `json:"eventDate"`
+	} `json:"events"`
+	Entities []struct {
+		Roles      []string        `json:"roles"`
+		VCardArray json.RawMessage `json:"vcardArray"`
+	} `json:"entities"`
+}
+
+// checkDomain 通过RDAP查询域名注册到期时间
+func (c *CertificateExpiryCollector) checkDomain(ctx context.Context, domain string) DomainExpiryInfo {
+	now := time.Now()
+	info := DomainExpiryInfo{Domain: domain, CheckedAt: now}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.rdapURL+domain, nil)
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	req.Header.Set("Accept", "application/rdap+json")
+	resp, err := c.client.Do(rdapDependency, req)
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		info.Error = fmt.Sprintf("RDAP returned %d", resp.StatusCode)
+		return info
+	}
+
+	var result rdapDomain
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		info.Error = "invalid RDAP response: " + err.Error()
+		return info
+	}
+	for _, event := range result.Events {
+		if event.EventAction != "expiration" {
+			continue
+		}
+		expiresAt, err := time.Parse(time.RFC3339, event.EventDate)
+		if err != nil {
+			info.Error = fmt.Sprintf("invalid expiration date: %q", event.EventDate)
+			return info
+		}
+		info.ExpiresAt = expiresAt
+		info.DaysRemaining = certificateDays(expiresAt.Sub(now))
+	}
+	if info.ExpiresAt.IsZero() {
+		info.Error = "RDAP response has no expiration event"
+		return info
+	}
+	for _, entity := range result.Entities {
+		if containsString(entity.Roles, "registrar") {
+			info.Registrar = rdapVCardName(entity.VCardArray)
+			break
+		}
+	}
+	return info
+}
+
+// rdapVCardName 从jCard中读取名称（fn），格式为 ["vcard", [["fn", {}, "text", "名称"], ...]]
+func rdapVCardName(raw json.RawMessage) string {
+	var card []json.RawMessage
+	if err := json.Unmarshal(raw, &card); err != nil || len(card) < 2 {
+		return ""
+	}
+	var properties [][]interface{}
+	if err := json.Unmarshal(card[1], &properties); err != nil {
+		return ""
+	}
+	for _, property := range properties {
+		if len(property) >= 4 && property[0] == "fn" {
+			name, _ := property[3].(string)
+			return name
+		}
+	}
+	return ""
+}
+
+// certificateDays 将剩余时长换算为天数，保留两位小数
+func certificateDays(remaining time.Duration) float64 {
+	return float64(int64(remaining.Hours()/24*100)) / 100
+}
+
+// boolMetric 布尔值转换为指标值
+func boolMetric(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
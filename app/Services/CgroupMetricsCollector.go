@@ -0,0 +1,289 @@
+package Services
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCgroupNotDetected 未识别到cgroup文件系统，通常表示未运行在容器中
+var ErrCgroupNotDetected = errors.New("未识别到cgroup文件系统")
+
+// cgroupUnlimitedMemory cgroup v1 未设置内存限额时 memory.limit_in_bytes 的值接近该值（按页对齐的 int64 最大值）
+const cgroupUnlimitedMemory = 1 << 62
+
+// cgroupSample 一次采集读取的累计计数，用于计算两次采集之间的使用率
+type cgroupSample struct {
+	at               time.Time
+	usageSeconds     float64
+	periods          float64
+	throttledPeriods float64
+}
+
+// CgroupMetricsCollector 容器资源指标采集器
+// 功能说明：
+// 1. 启动时识别cgroup v2（统一层级）或v1（按子系统挂载），读取容器的CPU配额、内存限额和使用量
+// 2. CPU指标：container_cpu_limit_cores、container_cpu_usage_seconds_total、container_cpu_periods_total、container_cpu_throttled_periods_total、container_cpu_throttled_seconds_total、container_cpu_throttled_ratio（百分比）
+// 3. 内存指标：container_memory_usage_bytes、container_memory_working_set_bytes、container_memory_limit_bytes、container_memory_oom_kills_total
+// 4. cpu_usage 按CPU配额（未设置配额时按可用核数）计算百分比，memory_usage 按工作集占内存限额计算百分比；在运行时采集器之后注册，覆盖同名指标，使CPU、内存阈值告警按容器限额判断
+// 5. 未设置内存限额时不输出 memory_usage，保留运行时采集器的值
+type CgroupMetricsCollector struct {
+	version   string // v1 或 v2
+	cpuDir    string
+	cpuacct   string
+	memoryDir string
+	now       func() time.Time
+
+	mu   sync.Mutex
+	last *cgroupSample
+}
+
+// NewCgroupMetricsCollector 识别 root 下的cgroup层级并创建采集器，未识别到时返回 ErrCgroupNotDetected
+func NewCgroupMetricsCollector(root string) (*CgroupMetricsCollector, error) {
+	collector := &CgroupMetricsCollector{now: time.Now}
+
+	if fileExists(filepath.Join(root, "cgroup.controllers")) {
+		collector.version = "v2"
+		collector.cpuDir = root
+		collector.cpuacct = root
+		collector.memoryDir = root
+		return collector, nil
+	}
+
+	// cgroup v1 的CPU子系统常见挂载为 cpu,cpuacct，也可能分开挂载
+	collector.cpuDir = firstCgroupDir(root, "cpu.cfs_quota_us", "cpu,cpuacct", "cpu", "cpuacct,cpu")
+	collector.cpuacct = firstCgroupDir(root, "cpuacct.usage", "cpu,cpuacct", "cpuacct", "cpuacct,cpu")
+	collector.memoryDir = firstCgroupDir(root, "memory.usage_in_bytes", "memory")
+	if collector.cpuDir == "" && collector.cpuacct == "" && collector.memoryDir == "" {
+		return nil, fmt.Errorf("%w: %s", ErrCgroupNotDetected, root)
+	}
+	collector.version = "v1"
+	return collector, nil
+}
+
+// firstCgroupDir 返回 root 下第一个包含 file 的子系统目录
+func firstCgroupDir(root, file string, dirs ...string) string {
+	for _, dir := range dirs {
+		path := filepath.Join(root, dir)
+		if fileExists(filepath.Join(path, file)) {
+			return path
+		}
+	}
+	return ""
+}
+
+// fileExists 文件是否存在
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Name 采集器名称
+func (c *CgroupMetricsCollector) Name() string {
+	return "cgroup"
+}
+
+// Version 识别到的cgroup版本
+func (c *CgroupMetricsCollector) Version() string {
+	return c.version
+}
+
+// SetClock 设置计算使用率使用的时钟
+func (c *CgroupMetricsCollector) SetClock(now func() time.Time) {
+	c.now = now
+}
+
+// Collect 读取cgroup文件并计算指标
+// CPU使用率和限流比例需要两次采集的差值，首次采集只输出累计值和限额
+func (c *CgroupMetricsCollector) Collect(ctx context.Context) (map[string]float64, error) {
+	var (
+		metrics map[string]float64
+		sample  cgroupSample
+		err     error
+	)
+	if c.version == "v2" {
+		metrics, sample, err = c.collectV2()
+	} else {
+		metrics, sample, err = c.collectV1()
+	}
+	if err != nil {
+		return nil, err
+	}
+	sample.at = c.now()
+
+	limitCores := metrics["container_cpu_limit_cores"]
+	if limitCores <= 0 {
+		limitCores = float64(runtime.NumCPU())
+	}
+
+	c.mu.Lock()
+	last := c.last
+	c.last = &sample
+	c.mu.Unlock()
+
+	if last != nil {
+		if elapsed := sample.at.Sub(last.at).Seconds(); c.cpuacct != "" && elapsed > 0 && sample.usageSeconds >= last.usageSeconds {
+			metrics["cpu_usage"] = (sample.usageSeconds - last.usageSeconds) / elapsed / limitCores * 100
+		}
+		if periods := sample.periods - last.periods; periods > 0 {
+			metrics["container_cpu_throttled_ratio"] = (sample.throttledPeriods - last.throttledPeriods) / periods * 100
+		} else {
+			metrics["container_cpu_throttled_ratio"] = 0
+		}
+	}
+
+	if limit := metrics["container_memory_limit_bytes"]; limit > 0 {
+		metrics["memory_usage"] = metrics["container_memory_working_set_bytes"] / limit * 100
+	}
+	return metrics, nil
+}
+
+// collectV2 读取统一层级下的 cpu.max、cpu.stat、memory.current、memory.max、memory.stat、memory.events
+func (c *CgroupMetricsCollector) collectV2() (map[string]float64, cgroupSample, error) {
+	metrics := make(map[string]float64)
+	var sample cgroupSample
+
+	// cpu.max 格式为 "$MAX $PERIOD"，$MAX 为 max 表示不限制
+	if fields, err := readCgroupFields(filepath.Join(c.cpuDir, "cpu.max")); err == nil && len(fields) == 2 && fields[0] != "max" {
+		quota, quotaErr := strconv.ParseFloat(fields[0], 64)
+		period, periodErr := strconv.ParseFloat(fields[1], 64)
+		if quotaErr == nil && periodErr == nil && period > 0 {
+			metrics["container_cpu_limit_cores"] = quota / period
+		}
+	}
+
+	stat, err := readCgroupStat(filepath.Join(c.cpuDir, "cpu.stat"))
+	if err != nil {
+		return nil, sample, err
+	}
+	sample.usageSeconds = stat["usage_usec"] / 1e6
+	sample.periods = stat["nr_periods"]
+	sample.throttledPeriods = stat["nr_throttled"]
+	metrics["container_cpu_usage_seconds_total"] = sample.usageSeconds
+	metrics["container_cpu_periods_total"] = sample.periods
+	metrics["container_cpu_throttled_periods_total"] = sample.throttledPeriods
+	metrics["container_cpu_throttled_seconds_total"] = stat["throttled_usec"] / 1e6
+
+	usage, err := readCgroupValue(filepath.Join(c.memoryDir, "memory.current"))
+	if err != nil {
+		return nil, sample, err
+	}
+	metrics["container_memory_usage_bytes"] = usage
+	if fields, err := readCgroupFields(filepath.Join(c.memoryDir, "memory.max")); err == nil && len(fields) == 1 && fields[0] != "max" {
+		if limit, err := strconv.ParseFloat(fields[0], 64); err == nil {
+			metrics["container_memory_limit_bytes"] = limit
+		}
+	}
+	memoryStat, _ := readCgroupStat(filepath.Join(c.memoryDir, "memory.stat"))
+	metrics["container_memory_working_set_bytes"] = cgroupWorkingSet(usage, memoryStat["inactive_file"])
+	if events, err := readCgroupStat(filepath.Join(c.memoryDir, "memory.events")); err == nil {
+		metrics["container_memory_oom_kills_total"] = events["oom_kill"]
+	}
+	return metrics, sample, nil
+}
+
+// collectV1 读取 cpu、cpuacct、memory 子系统的文件
+func (c *CgroupMetricsCollector) collectV1() (map[string]float64, cgroupSample, error) {
+	metrics := make(map[string]float64)
+	var sample cgroupSample
+
+	if c.cpuDir != "" {
+		// cpu.cfs_quota_us 为 -1 表示不限制
+		quota, quotaErr := readCgroupValue(filepath.Join(c.cpuDir, "cpu.cfs_quota_us"))
+		period, periodErr := readCgroupValue(filepath.Join(c.cpuDir, "cpu.cfs_period_us"))
+		if quotaErr == nil && periodErr == nil && quota > 0 && period > 0 {
+			metrics["container_cpu_limit_cores"] = quota / period
+		}
+		if stat, err := readCgroupStat(filepath.Join(c.cpuDir, "cpu.stat")); err == nil {
+			sample.periods = stat["nr_periods"]
+			sample.throttledPeriods = stat["nr_throttled"]
+			metrics["container_cpu_periods_total"] = sample.periods
+			metrics["container_cpu_throttled_periods_total"] = sample.throttledPeriods
+			metrics["container_cpu_throttled_seconds_total"] = stat["throttled_time"] / 1e9
+		}
+	}
+	if c.cpuacct != "" {
+		usage, err := readCgroupValue(filepath.Join(c.cpuacct, "cpuacct.usage"))
+		if err != nil {
+			return nil, sample, err
+		}
+		sample.usageSeconds = usage / 1e9
+		metrics["container_cpu_usage_seconds_total"] = sample.usageSeconds
+	}
+
+	if c.memoryDir != "" {
+		usage, err := readCgroupValue(filepath.Join(c.memoryDir, "memory.usage_in_bytes"))
+		if err != nil {
+			return nil, sample, err
+		}
+		metrics["container_memory_usage_bytes"] = usage
+		if limit, err := readCgroupValue(filepath.Join(c.memoryDir, "memory.limit_in_bytes")); err == nil && limit > 0 && limit < cgroupUnlimitedMemory {
+			metrics["container_memory_limit_bytes"] = limit
+		}
+		memoryStat, _ := readCgroupStat(filepath.Join(c.memoryDir, "memory.stat"))
+		metrics["container_memory_working_set_bytes"] = cgroupWorkingSet(usage, memoryStat["total_inactive_file"])
+		if oomControl, err := readCgroupStat(filepath.Join(c.memoryDir, "memory.oom_control")); err == nil {
+			metrics["container_memory_oom_kills_total"] = oomControl["oom_kill"]
+		}
+	}
+	return metrics, sample, nil
+}
+
+// cgroupWorkingSet 工作集 = 使用量 - 非活跃文件缓存，与kubelet判断内存压力和OOM的口径一致
+func cgroupWorkingSet(usage, inactiveFile float64) float64 {
+	if inactiveFile > usage {
+		return 0
+	}
+	return usage - inactiveFile
+}
+
+// readCgroupFields 读取单行文件并按空白拆分
+func readCgroupFields(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(data)), nil
+}
+
+// readCgroupValue 读取只包含一个数值的文件
+func readCgroupValue(path string) (float64, error) {
+	fields, err := readCgroupFields(path)
+	if err != nil {
+		return 0, err
+	}
+	if len(fields) != 1 {
+		return 0, fmt.Errorf("unexpected content in %s", path)
+	}
+	return strconv.ParseFloat(fields[0], 64)
+}
+
+// readCgroupStat 读取 "键 值" 格式的统计文件，如 cpu.stat、memory.stat
+func readCgroupStat(path string) (map[string]float64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stat := make(map[string]float64)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if value, err := strconv.ParseFloat(fields[1], 64); err == nil {
+			stat[fields[0]] = value
+		}
+	}
+	return stat, scanner.Err()
+}
@@ -0,0 +1,477 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCleanupJobNotFound 清理任务不存在
+	ErrCleanupJobNotFound = errors.New("清理任务不存在")
+	// ErrCleanupJobRunning 清理任务正在运行
+	ErrCleanupJobRunning = errors.New("清理任务正在运行")
+	// ErrCleanupJobNotRunning 清理任务没有在运行
+	ErrCleanupJobNotRunning = errors.New("清理任务没有在运行")
+)
+
+// 清理任务的运行结果
+const (
+	CleanupResultSuccess   = "success"
+	CleanupResultFailed    = "failed"
+	CleanupResultCancelled = "cancelled" // 管理员取消或服务停止
+	CleanupResultTimeout   = "timeout"   // 超过单次最长运行时间，剩余记录下次继续清理
+)
+
+// 分批删除的默认参数，未加载配置时使用
+const (
+	defaultCleanupBatchSize  = 1000
+	defaultCleanupBatchPause = 100 * time.Millisecond
+)
+
+// CleanupFunc 清理任务，返回删除的记录数；需要在 ctx 取消时尽快返回
+type CleanupFunc func(ctx context.Context) (int64, error)
+
+// CleanupJobStatus 清理任务状态
+type CleanupJobStatus struct {
+	Name             string     `json:"name"`
+	Description      string     `json:"description"`
+	Interval         string     `json:"interval"` // 自动运行间隔
+	Running          bool       `json:"running"`
+	RowsDeleted      int64      `json:"rows_deleted"` // 当前运行或最近一次运行删除的记录数
+	Batches          int64      `json:"batches"`      // 当前运行或最近一次运行删除的批数
+	StartedAt        *time.Time `json:"started_at,omitempty"`
+	FinishedAt       *time.Time `json:"finished_at,omitempty"`
+	DurationMS       int64      `json:"duration_ms"`
+	LastResult       string     `json:"last_result,omitempty"`
+	LastError        string     `json:"last_error,omitempty"`
+	Runs             int64      `json:"runs"`
+	Failures         int64      `json:"failures"`
+	TotalRowsDeleted int64      `json:"total_rows_deleted"`
+}
+
+// BatchDeleteOptions 分批删除参数
+type BatchDeleteOptions struct {
+	BatchSize int           // 每批删除的记录数
+	Pause     time.Duration // 批次之间的暂停时间
+}
+
+// cleanupRunKey 上下文中清理任务单次运行的键
+type cleanupRunKey struct{}
+
+// cleanupRun 清理任务单次运行的分批参数和进度，由清理调度器放入 ctx，BatchDelete 更新进度
+type cleanupRun struct {
+	options BatchDeleteOptions
+	deleted atomic.Int64
+	batches atomic.Int64
+}
+
+type cleanupJob struct {
+	status    CleanupJobStatus
+	interval  time.Duration
+	fn        CleanupFunc
+	run       *cleanupRun
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+// CleanupScheduler 过期数据清理调度器
+// 功能说明：
+// 1. 各服务的过期数据清理注册为清理任务，按任务的间隔（默认 CLEANUP_INTERVAL）依次运行，同一时间只运行一个任务，避免同时锁多张表
+// 2. 任务通过 BatchDelete 按批删除，每批最多 CLEANUP_BATCH_SIZE 条，批次之间暂停 CLEANUP_BATCH_PAUSE
+// 3. 记录每个任务的进度、删除的记录数和运行结果，通过 Collector 输出为监控指标
+// 4. 管理员可以手动触发或取消任务；单次运行超过 CLEANUP_MAX_RUN_DURATION 时停止，剩余记录下次继续清理
+type CleanupScheduler struct {
+	config *Config.CleanupConfig
+	mu     sync.Mutex
+	jobs   map[string]*cleanupJob
+	order  []string
+	wg     sync.WaitGroup
+	now    func() time.Time
+}
+
+// NewCleanupScheduler 创建清理调度器，config 为 nil 时使用默认配置
+func NewCleanupScheduler(config *Config.CleanupConfig) *CleanupScheduler {
+	if config == nil {
+		config = &Config.CleanupConfig{
+			Enabled:        true,
+			Interval:       time.Hour,
+			BatchSize:      defaultCleanupBatchSize,
+			BatchPause:     defaultCleanupBatchPause,
+			MaxRunDuration: 10 * time.Minute,
+		}
+	}
+	return &CleanupScheduler{
+		config: config,
+		jobs:   make(map[string]*cleanupJob),
+		now:    time.Now,
+	}
+}
+
+// Register 注册清理任务，interval 为自动运行间隔，为0时使用 CLEANUP_INTERVAL；同名任务替换原任务
+func (s *CleanupScheduler) Register(name, description string, interval time.Duration, fn CleanupFunc) {
+	if interval <= 0 {
+		interval = s.config.Interval
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, exists := s.jobs[name]
+	if !exists {
+		job = &cleanupJob{status: CleanupJobStatus{Name: name}}
+		s.jobs[name] = job
+		s.order = append(s.order, name)
+	}
+	job.fn = fn
+	job.interval = interval
+	job.status.Description = description
+	job.status.Interval = interval.String()
+}
+
+// Jobs 返回全部清理任务的状态，按注册顺序排列
+func (s *CleanupScheduler) Jobs() []CleanupJobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]CleanupJobStatus, 0, len(s.order))
+	for _, name := range s.order {
+		statuses = append(statuses, s.jobs[name].snapshot())
+	}
+	return statuses
+}
+
+// Job 返回清理任务的状态
+func (s *CleanupScheduler) Job(name string) (CleanupJobStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, exists := s.jobs[name]
+	if !exists {
+		return CleanupJobStatus{}, ErrCleanupJobNotFound
+	}
+	return job.snapshot(), nil
+}
+
+// Run 运行清理任务并等待完成，返回运行后的状态；任务正在运行时返回 ErrCleanupJobRunning
+func (s *CleanupScheduler) Run(ctx context.Context, name string) (CleanupJobStatus, error) {
+	job, jobCtx, err := s.begin(ctx, name)
+	if err != nil {
+		return CleanupJobStatus{}, err
+	}
+	return s.execute(ctx, jobCtx, job), nil
+}
+
+// Trigger 在后台运行清理任务，返回开始运行时的状态
+func (s *CleanupScheduler) Trigger(name string) (CleanupJobStatus, error) {
+	job, jobCtx, err := s.begin(context.Background(), name)
+	if err != nil {
+		return CleanupJobStatus{}, err
+	}
+	s.mu.Lock()
+	status := job.snapshot()
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.execute(context.Background(), jobCtx, job)
+	}()
+	return status, nil
+}
+
+// Cancel 取消正在运行的清理任务，已删除的批次不回滚
+func (s *CleanupScheduler) Cancel(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, exists := s.jobs[name]
+	if !exists {
+		return ErrCleanupJobNotFound
+	}
+	if !job.status.Running {
+		return ErrCleanupJobNotRunning
+	}
+	job.cancelled = true
+	job.cancel()
+	return nil
+}
+
+// RunAll 依次运行全部清理任务，ctx 取消后不再运行剩余任务；正在运行的任务跳过
+func (s *CleanupScheduler) RunAll(ctx context.Context) []CleanupJobStatus {
+	s.mu.Lock()
+	names := append([]string(nil), s.order...)
+	s.mu.Unlock()
+
+	statuses := make([]CleanupJobStatus, 0, len(names))
+	for _, name := range names {
+		if ctx.Err() != nil {
+			break
+		}
+		status, err := s.Run(ctx, name)
+		if err != nil {
+			continue
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Start 在后台按各任务的间隔运行清理任务，启动时先运行一次；未启用自动清理时不启动，ctx 取消时停止
+func (s *CleanupScheduler) Start(ctx context.Context) {
+	if !s.config.Enabled || s.config.Interval <= 0 {
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			s.runDue(ctx)
+			timer := time.NewTimer(s.checkInterval())
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+}
+
+// runDue 依次运行距上次开始运行已超过间隔的任务
+func (s *CleanupScheduler) runDue(ctx context.Context) {
+	s.mu.Lock()
+	now := s.now()
+	var due []string
+	for _, name := range s.order {
+		job := s.jobs[name]
+		if job.status.StartedAt == nil || now.Sub(*job.status.StartedAt) >= job.interval {
+			due = append(due, name)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, name := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		s.Run(ctx, name)
+	}
+}
+
+// checkInterval 检查到期任务的间隔，取各任务间隔的最小值
+func (s *CleanupScheduler) checkInterval() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	interval := s.config.Interval
+	for _, job := range s.jobs {
+		if job.interval < interval {
+			interval = job.interval
+		}
+	}
+	return max(interval, time.Second)
+}
+
+// Wait 等待后台运行的任务结束，调用前需要先取消 Start 的 ctx
+func (s *CleanupScheduler) Wait() {
+	s.wg.Wait()
+}
+
+// Collector 返回清理任务的监控指标采集器
+func (s *CleanupScheduler) Collector() MetricCollector {
+	return NewMetricCollector("cleanup", func(ctx context.Context) (map[string]float64, error) {
+		jobs := s.Jobs()
+		values := map[string]float64{}
+		var running, deleted, failures float64
+		for _, job := range jobs {
+			prefix := "cleanup_" + job.Name
+			values[prefix+"_rows_deleted_total"] = float64(job.TotalRowsDeleted)
+			values[prefix+"_last_rows_deleted"] = float64(job.RowsDeleted)
+			values[prefix+"_last_duration_seconds"] = float64(job.DurationMS) / 1000
+			values[prefix+"_failures_total"] = float64(job.Failures)
+			values[prefix+"_running"] = 0
+			if job.Running {
+				values[prefix+"_running"] = 1
+				running++
+			}
+			deleted += float64(job.TotalRowsDeleted)
+			failures += float64(job.Failures)
+		}
+		values["cleanup_jobs"] = float64(len(jobs))
+		values["cleanup_running_jobs"] = running
+		values["cleanup_rows_deleted_total"] = deleted
+		values["cleanup_failures_total"] = failures
+		return values, nil
+	})
+}
+
+// begin 标记任务开始运行，返回任务的 ctx；ctx 带有分批参数和进度，超过单次最长运行时间时取消
+func (s *CleanupScheduler) begin(ctx context.Context, name string) (*cleanupJob, context.Context, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, exists := s.jobs[name]
+	if !exists {
+		return nil, nil, ErrCleanupJobNotFound
+	}
+	if job.status.Running {
+		return nil, nil, ErrCleanupJobRunning
+	}
+
+	var jobCtx context.Context
+	var cancel context.CancelFunc
+	if s.config.MaxRunDuration > 0 {
+		jobCtx, cancel = context.WithTimeout(ctx, s.config.MaxRunDuration)
+	} else {
+		jobCtx, cancel = context.WithCancel(ctx)
+	}
+	job.run = &cleanupRun{options: BatchDeleteOptions{BatchSize: s.config.BatchSize, Pause: s.config.BatchPause}}
+	job.cancel = cancel
+	job.cancelled = false
+
+	startedAt := s.now()
+	job.status.Running = true
+	job.status.StartedAt = &startedAt
+	job.status.FinishedAt = nil
+	job.status.RowsDeleted = 0
+	job.status.Batches = 0
+	return job, context.WithValue(jobCtx, cleanupRunKey{}, job.run), nil
+}
+
+// execute 运行任务并记录结果
+func (s *CleanupScheduler) execute(parent, ctx context.Context, job *cleanupJob) CleanupJobStatus {
+	deleted, err := s.safeRun(ctx, job.fn)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job.cancel()
+	if progress := job.run.deleted.Load(); progress > deleted {
+		deleted = progress
+	}
+	finishedAt := s.now()
+	status := &job.status
+	status.Running = false
+	status.FinishedAt = &finishedAt
+	status.DurationMS = finishedAt.Sub(*status.StartedAt).Milliseconds()
+	status.RowsDeleted = deleted
+	status.Batches = job.run.batches.Load()
+	status.TotalRowsDeleted += deleted
+	status.Runs++
+	status.LastError = ""
+	switch {
+	case err == nil:
+		status.LastResult = CleanupResultSuccess
+	case job.cancelled || (errors.Is(err, context.Canceled) && parent.Err() != nil):
+		status.LastResult = CleanupResultCancelled
+	case errors.Is(err, context.DeadlineExceeded) && parent.Err() == nil:
+		status.LastResult = CleanupResultTimeout
+	default:
+		status.LastResult = CleanupResultFailed
+		status.LastError = err.Error()
+		status.Failures++
+	}
+	if err != nil {
+		log.Printf("清理任务 %s 未完成 (%s): 已删除 %d 条记录, error=%v", status.Name, status.LastResult, deleted, err)
+	} else if deleted > 0 {
+		log.Printf("清理任务 %s 已删除 %d 条过期记录", status.Name, deleted)
+	}
+	return job.snapshot()
+}
+
+// safeRun 运行任务，任务 panic 时转换为错误
+func (s *CleanupScheduler) safeRun(ctx context.Context, fn CleanupFunc) (deleted int64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("清理任务异常: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
+
+// snapshot 返回任务状态的副本，调用方需要持有调度器的锁
+func (j *cleanupJob) snapshot() CleanupJobStatus {
+	status := j.status
+	if status.Running && j.run != nil {
+		status.RowsDeleted = j.run.deleted.Load()
+		status.Batches = j.run.batches.Load()
+	}
+	return status
+}
+
+// BatchDelete 按主键分批删除 query 匹配的记录，返回删除的记录数
+// 在清理任务中运行时使用清理调度器的分批参数并更新任务进度，否则使用 CLEANUP_BATCH_SIZE 和 CLEANUP_BATCH_PAUSE
+// ctx 取消时在当前批次完成后返回，已删除的批次不回滚；model 需要有 uint 类型的 id 主键
+func BatchDelete(ctx context.Context, query *gorm.DB, model interface{}) (int64, error) {
+	run, _ := ctx.Value(cleanupRunKey{}).(*cleanupRun)
+	options := cleanupBatchOptions(ctx)
+
+	query = query.WithContext(ctx)
+	var deleted int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+		var ids []uint
+		if err := query.Session(&gorm.Session{}).Model(model).Order("id").Limit(options.BatchSize).Pluck("id", &ids).Error; err != nil {
+			return deleted, err
+		}
+		if len(ids) == 0 {
+			return deleted, nil
+		}
+
+		tx := query.Session(&gorm.Session{NewDB: true})
+		if query.Statement.Unscoped {
+			tx = tx.Unscoped()
+		}
+		result := tx.Where("id IN ?", ids).Delete(model)
+		if result.Error != nil {
+			return deleted, result.Error
+		}
+		deleted += result.RowsAffected
+		if run != nil {
+			run.deleted.Add(result.RowsAffected)
+			run.batches.Add(1)
+		}
+		if len(ids) < options.BatchSize {
+			return deleted, nil
+		}
+		if err := sleepContext(ctx, options.Pause); err != nil {
+			return deleted, err
+		}
+	}
+}
+
+// cleanupBatchOptions 返回分批参数，在清理任务中运行时使用清理调度器的参数，否则使用全局配置
+func cleanupBatchOptions(ctx context.Context) BatchDeleteOptions {
+	options := BatchDeleteOptions{BatchSize: defaultCleanupBatchSize, Pause: defaultCleanupBatchPause}
+	if run, ok := ctx.Value(cleanupRunKey{}).(*cleanupRun); ok {
+		options = run.options
+	} else if config := Config.GetCleanupConfig(); config != nil {
+		options = BatchDeleteOptions{BatchSize: config.BatchSize, Pause: config.BatchPause}
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = defaultCleanupBatchSize
+	}
+	return options
+}
+
+var (
+	defaultCleanupScheduler   *CleanupScheduler
+	defaultCleanupSchedulerMu sync.RWMutex
+)
+
+// SetDefaultCleanupScheduler 设置全局清理调度器
+func SetDefaultCleanupScheduler(scheduler *CleanupScheduler) {
+	defaultCleanupSchedulerMu.Lock()
+	defer defaultCleanupSchedulerMu.Unlock()
+	defaultCleanupScheduler = scheduler
+}
+
+// DefaultCleanupScheduler 获取全局清理调度器，未设置时返回 nil
+func DefaultCleanupScheduler() *CleanupScheduler {
+	defaultCleanupSchedulerMu.RLock()
+	defer defaultCleanupSchedulerMu.RUnlock()
+	return defaultCleanupScheduler
+}
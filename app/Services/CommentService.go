@@ -0,0 +1,443 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Models"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCommentNotFound 评论不存在或已删除
+	ErrCommentNotFound = errors.New("评论不存在")
+	// ErrInvalidCommentResource 不支持的评论资源类型
+	ErrInvalidCommentResource = errors.New("不支持的评论资源类型")
+	// ErrCommentResourceNotFound 评论的资源不存在
+	ErrCommentResourceNotFound = errors.New("评论的资源不存在")
+	// ErrInvalidComment 评论内容为空或过长
+	ErrInvalidComment = errors.New("评论内容不能为空且不能超过5000个字符")
+	// ErrCommentForbidden 无权查看或修改评论
+	ErrCommentForbidden = errors.New("无权操作该评论")
+)
+
+// maxCommentLength 评论内容的最大字符数
+const maxCommentLength = 5000
+
+// commentMentionPattern 匹配 @用户名，@ 前不能是字母数字，避免把邮箱地址当作提及
+var commentMentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9_.@])@([A-Za-z0-9_][A-Za-z0-9_.\-]*)`)
+
+// commentResourceLabels 资源类型在通知中的名称
+var commentResourceLabels = map[string]string{
+	Models.CommentResourceAlert:         "告警",
+	Models.CommentResourceSecurityEvent: "安全事件",
+	Models.CommentResourceReport:        "监控报告",
+}
+
+// CommentActor 评论操作者
+type CommentActor struct {
+	UserID        uint
+	IsAdmin       bool
+	IPAddress     string
+	RequestID     string
+	CorrelationID string
+}
+
+// CommentThread 顶层评论及其回复
+// 已删除的评论只在仍有回复时保留占位，内容和提及被清空
+type CommentThread struct {
+	Models.Comment
+	Deleted bool            `json:"deleted"`
+	Replies []CommentThread `json:"replies,omitempty"`
+}
+
+// CommentService 评论服务
+// 功能说明：
+// 1. 告警、安全事件和监控报告支持两级线程评论，回复其他回复时归到同一顶层评论下
+// 2. 评论中的 @用户名 给被提及的用户发送站内通知，编辑时只通知新增提及的用户；监控报告只有管理员可以查看，只通知管理员
+// 3. 作者可以编辑自己的评论，作者和管理员可以删除评论，编辑和删除写入审计日志并保留修改前内容
+// 4. 按资源导出未删除的评论记录，用于告警和事件复盘导出
+type CommentService struct {
+	db   *gorm.DB
+	core *MonitoringCore
+	now  func() time.Time
+}
+
+// NewCommentService 创建评论服务
+func NewCommentService(db *gorm.DB) *CommentService {
+	return &CommentService{db: db, now: time.Now}
+}
+
+// SetMonitoringCore 设置监控核心，用于校验告警是否存在
+// 未设置时不校验告警ID
+func (s *CommentService) SetMonitoringCore(core *MonitoringCore) {
+	s.core = core
+}
+
+// Create 发表评论，parentID 不为空时回复该评论
+func (s *CommentService) Create(actor CommentActor, resourceType, resourceID string, parentID *uint, content string) (*Models.Comment, error) {
+	content = strings.TrimSpace(content)
+	if err := validateCommentContent(content); err != nil {
+		return nil, err
+	}
+	if err := s.checkResource(actor, resourceType, resourceID); err != nil {
+		return nil, err
+	}
+
+	var author Models.User
+	if err := s.db.Select("id", "username").First(&author, actor.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCommentForbidden
+		}
+		return nil, err
+	}
+
+	comment := &Models.Comment{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		UserID:       author.ID,
+		Username:     author.Username,
+		Content:      content,
+	}
+	if parentID != nil {
+		var parent Models.Comment
+		err := s.db.Where("resource_type = ? AND resource_id = ?", resourceType, resourceID).First(&parent, *parentID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCommentNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		rootID := parent.ID
+		if parent.ParentID != nil {
+			rootID = *parent.ParentID
+		}
+		comment.ParentID = &rootID
+	}
+
+	mentioned, err := s.resolveMentions(resourceType, content, author.ID)
+	if err != nil {
+		return nil, err
+	}
+	comment.Mentions = mentionNames(mentioned)
+	if err := s.db.Create(comment).Error; err != nil {
+		return nil, err
+	}
+	s.notifyMentions(comment, mentioned)
+	return comment, nil
+}
+
+// List 获取资源的评论线程，按发表时间正序
+func (s *CommentService) List(actor CommentActor, resourceType, resourceID string) ([]CommentThread, error) {
+	if err := s.checkAccess(actor, resourceType); err != nil {
+		return nil, err
+	}
+
+	var comments []Models.Comment
+	err := s.db.Unscoped().
+		Where("resource_type = ? AND resource_id = ?", resourceType, resourceID).
+		Order("created_at ASC, id ASC").
+		Find(&comments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	replies := make(map[uint][]CommentThread)
+	for _, comment := range comments {
+		if comment.ParentID != nil && !comment.DeletedAt.Valid {
+			replies[*comment.ParentID] = append(replies[*comment.ParentID], CommentThread{Comment: comment})
+		}
+	}
+	threads := make([]CommentThread, 0)
+	for _, comment := range comments {
+		if comment.ParentID != nil {
+			continue
+		}
+		thread := CommentThread{Comment: comment, Replies: replies[comment.ID]}
+		if comment.DeletedAt.Valid {
+			if len(thread.Replies) == 0 {
+				continue
+			}
+			thread.Deleted = true
+			thread.Content = ""
+			thread.Mentions = ""
+		}
+		threads = append(threads, thread)
+	}
+	return threads, nil
+}
+
+// Update 编辑评论，只有作者可以编辑
+func (s *CommentService) Update(id uint, actor CommentActor, content string) (*Models.Comment, error) {
+	content = strings.TrimSpace(content)
+	if err := validateCommentContent(content); err != nil {
+		return nil, err
+	}
+	comment, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+	if comment.UserID != actor.UserID {
+		return nil, ErrCommentForbidden
+	}
+	if err := s.checkAccess(actor, comment.ResourceType); err != nil {
+		return nil, err
+	}
+
+	before := *comment
+	previous := make(map[string]bool)
+	for _, name := range strings.Split(comment.Mentions, ",") {
+		previous[name] = true
+	}
+	mentioned, err := s.resolveMentions(comment.ResourceType, content, comment.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	comment.Content = content
+	comment.Mentions = mentionNames(mentioned)
+	comment.EditedAt = &now
+	err = s.db.Model(comment).Updates(map[string]interface{}{
+		"content":   comment.Content,
+		"mentions":  comment.Mentions,
+		"edited_at": now,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(Models.NewAuditLog(actor.UserID, comment.Username, Models.AuditActionCommentUpdate, "comment", comment.ID).
+		SetDescription(fmt.Sprintf("编辑%s %s 的评论", commentResourceLabels[comment.ResourceType], comment.ResourceID)).
+		SetIPAddress(actor.IPAddress).
+		SetRequestID(actor.RequestID).
+		SetCorrelationID(actor.CorrelationID).
+		SetBeforeData(before).
+		SetAfterData(comment).
+		SetMetadata(map[string]interface{}{"resource_type": comment.ResourceType, "resource_id": comment.ResourceID}))
+
+	added := make([]Models.User, 0, len(mentioned))
+	for _, user := range mentioned {
+		if !previous[user.Username] {
+			added = append(added, user)
+		}
+	}
+	s.notifyMentions(comment, added)
+	return comment, nil
+}
+
+// Delete 删除评论，作者和管理员可以删除
+func (s *CommentService) Delete(id uint, actor CommentActor) error {
+	comment, err := s.find(id)
+	if err != nil {
+		return err
+	}
+	if comment.UserID != actor.UserID && !actor.IsAdmin {
+		return ErrCommentForbidden
+	}
+	if err := s.checkAccess(actor, comment.ResourceType); err != nil {
+		return err
+	}
+
+	before := *comment
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(comment).Update("deleted_by", actor.UserID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(comment).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	username := comment.Username
+	if actor.UserID != comment.UserID {
+		var user Models.User
+		if err := s.db.Select("username").First(&user, actor.UserID).Error; err == nil {
+			username = user.Username
+		}
+	}
+	level := Models.AuditLevelInfo
+	if actor.UserID != comment.UserID {
+		level = Models.AuditLevelWarning
+	}
+	s.audit(Models.NewAuditLog(actor.UserID, username, Models.AuditActionCommentDelete, "comment", comment.ID).
+		SetLevel(level).
+		SetDescription(fmt.Sprintf("删除%s %s 上 %s 的评论", commentResourceLabels[comment.ResourceType], comment.ResourceID, comment.Username)).
+		SetIPAddress(actor.IPAddress).
+		SetRequestID(actor.RequestID).
+		SetCorrelationID(actor.CorrelationID).
+		SetBeforeData(before).
+		SetMetadata(map[string]interface{}{"resource_type": comment.ResourceType, "resource_id": comment.ResourceID}))
+	return nil
+}
+
+// Trail 获取多个资源未删除的评论，按发表时间正序，用于导出
+func (s *CommentService) Trail(resourceType string, resourceIDs []string) ([]Models.Comment, error) {
+	comments := make([]Models.Comment, 0)
+	if len(resourceIDs) == 0 {
+		return comments, nil
+	}
+	err := s.db.Where("resource_type = ? AND resource_id IN ?", resourceType, resourceIDs).
+		Order("created_at ASC, id ASC").
+		Find(&comments).Error
+	return comments, err
+}
+
+// find 获取未删除的评论
+func (s *CommentService) find(id uint) (*Models.Comment, error) {
+	var comment Models.Comment
+	err := s.db.First(&comment, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// checkAccess 校验资源类型和查看权限，监控报告只有管理员可以查看
+func (s *CommentService) checkAccess(actor CommentActor, resourceType string) error {
+	if _, ok := commentResourceLabels[resourceType]; !ok {
+		return ErrInvalidCommentResource
+	}
+	if resourceType == Models.CommentResourceReport && !actor.IsAdmin {
+		return ErrCommentForbidden
+	}
+	return nil
+}
+
+// checkResource 校验评论的资源存在
+func (s *CommentService) checkResource(actor CommentActor, resourceType, resourceID string) error {
+	if err := s.checkAccess(actor, resourceType); err != nil {
+		return err
+	}
+	if resourceID == "" {
+		return ErrCommentResourceNotFound
+	}
+
+	var err error
+	switch resourceType {
+	case Models.CommentResourceAlert:
+		if s.core == nil {
+			return nil
+		}
+		for _, alert := range s.core.Alerts("", 0) {
+			if alert.ID == resourceID {
+				return nil
+			}
+		}
+		return ErrCommentResourceNotFound
+	case Models.CommentResourceSecurityEvent:
+		err = s.findRecord(&Models.SecurityEvent{}, resourceID)
+	case Models.CommentResourceReport:
+		err = s.findRecord(&Models.MonitoringReport{}, resourceID)
+	}
+	return err
+}
+
+// findRecord 按数字ID查找资源记录
+func (s *CommentService) findRecord(model interface{}, resourceID string) error {
+	id, err := strconv.ParseUint(resourceID, 10, 32)
+	if err != nil || id == 0 {
+		return ErrCommentResourceNotFound
+	}
+	err = s.db.Select("id").First(model, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrCommentResourceNotFound
+	}
+	return err
+}
+
+// resolveMentions 查找评论中提及的启用用户，不包括作者本人
+func (s *CommentService) resolveMentions(resourceType, content string, authorID uint) ([]Models.User, error) {
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	for _, match := range commentMentionPattern.FindAllStringSubmatch(content, -1) {
+		name := strings.TrimRight(match[1], ".-")
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	users := make([]Models.User, 0)
+	if len(names) == 0 {
+		return users, nil
+	}
+
+	query := s.db.Select("id", "username", "role").
+		Where("username IN ? AND status = ? AND id <> ?", names, 1, authorID)
+	if resourceType == Models.CommentResourceReport {
+		query = query.Where("role = ?", "admin")
+	}
+	if err := query.Order("id ASC").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// notifyMentions 给被提及的用户发送站内通知，发送失败不影响评论
+func (s *CommentService) notifyMentions(comment *Models.Comment, users []Models.User) {
+	if len(users) == 0 {
+		return
+	}
+	notifier := DefaultNotificationService()
+	if notifier == nil {
+		notifier = NewNotificationService(s.db, nil)
+	}
+
+	label := commentResourceLabels[comment.ResourceType]
+	title := fmt.Sprintf("%s 在评论中提到了你", comment.Username)
+	content := fmt.Sprintf("%s %s：%s", label, comment.ResourceID, truncateCommentContent(comment.Content, 200))
+	for _, user := range users {
+		_, err := notifier.Notify(user.ID, Models.NotificationTypeMention, title, content, map[string]interface{}{
+			"comment_id":    comment.ID,
+			"resource_type": comment.ResourceType,
+			"resource_id":   comment.ResourceID,
+			"author":        comment.Username,
+		})
+		if err != nil {
+			log.Printf("发送评论提及通知失败: user=%d, comment=%d, error=%v", user.ID, comment.ID, err)
+		}
+	}
+}
+
+// audit 保存审计日志，保存失败不影响评论操作
+func (s *CommentService) audit(entry *Models.AuditLog) {
+	if err := s.db.Create(entry).Error; err != nil {
+		log.Printf("保存评论审计日志失败: %v", err)
+	}
+}
+
+// validateCommentContent 校验评论内容
+func validateCommentContent(content string) error {
+	if content == "" || utf8.RuneCountInString(content) > maxCommentLength {
+		return ErrInvalidComment
+	}
+	return nil
+}
+
+// mentionNames 把被提及的用户名拼接为逗号分隔的字符串
+func mentionNames(users []Models.User) string {
+	names := make([]string, 0, len(users))
+	for _, user := range users {
+		names = append(names, user.Username)
+	}
+	return strings.Join(names, ",")
+}
+
+// truncateCommentContent 截断通知中的评论内容
+func truncateCommentContent(content string, limit int) string {
+	runes := []rune(content)
+	if len(runes) <= limit {
+		return content
+	}
+	return string(runes[:limit]) + "..."
+}
@@ -0,0 +1,370 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 配置快照来源
+const (
+	ConfigSnapshotCapture = "capture"
+	ConfigSnapshotStartup = "startup"
+	ConfigSnapshotImport  = "import"
+)
+
+// 配置差异类型
+const (
+	ConfigChangeAdded   = "added"
+	ConfigChangeRemoved = "removed"
+	ConfigChangeChanged = "changed"
+)
+
+var (
+	// ErrConfigSnapshotNotFound 配置快照不存在
+	ErrConfigSnapshotNotFound = errors.New("配置快照不存在")
+	// ErrInvalidConfigSnapshot 配置快照参数无效
+	ErrInvalidConfigSnapshot = errors.New("配置快照参数无效")
+	// ErrConfigUnavailable 当前配置未加载
+	ErrConfigUnavailable = errors.New("当前配置未加载")
+)
+
+// ConfigSnapshotInput 生成和导入配置快照的参数
+type ConfigSnapshotInput struct {
+	Environment string                 `json:"environment"` // 环境，为空时使用 APP_ENV
+	Host        string                 `json:"host"`        // 导入时为来源主机
+	Label       string                 `json:"label"`       // 说明
+	Values      map[string]interface{} `json:"values"`      // 导入的配置，由其他环境的快照接口返回；生成快照时忽略
+}
+
+// ConfigChange 配置差异
+type ConfigChange struct {
+	Key  string      `json:"key"`
+	Type string      `json:"type"` // added、removed、changed
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+// ConfigSnapshotRef 差异两端的快照，ID为0表示当前配置
+type ConfigSnapshotRef struct {
+	ID          uint      `json:"id"`
+	Environment string    `json:"environment"`
+	Version     int       `json:"version"`
+	Label       string    `json:"label"`
+	Checksum    string    `json:"checksum"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ConfigDiff 两份配置的差异
+type ConfigDiff struct {
+	From    ConfigSnapshotRef `json:"from"`
+	To      ConfigSnapshotRef `json:"to"`
+	Changes []ConfigChange    `json:"changes"`
+	Added   int               `json:"added"`
+	Removed int               `json:"removed"`
+	Changed int               `json:"changed"`
+	Ignored []string          `json:"ignored,omitempty"` // 忽略的键前缀
+}
+
+// ConfigSnapshotService 配置快照服务
+// 功能说明：
+// 1. 将当前有效配置展开为 section.key 形式并脱敏（密码、密钥、令牌和URL中的密码），按环境保存带版本号的快照
+// 2. 启动时配置与本环境上一个快照不同时自动生成快照，记录每次部署的配置
+// 3. 比较两个快照或快照与当前配置，其他环境的快照可以导入后比较
+//
+// 敏感配置只保存是否设置，值的变化不会出现在差异中
+type ConfigSnapshotService struct {
+	db     *gorm.DB
+	config func() *Config.Config
+	now    func() time.Time
+}
+
+// NewConfigSnapshotService 创建配置快照服务
+func NewConfigSnapshotService(db *gorm.DB) *ConfigSnapshotService {
+	return &ConfigSnapshotService{
+		db:     db,
+		config: Config.GetConfig,
+		now:    time.Now,
+	}
+}
+
+// SetConfigSource 设置当前配置的来源，默认为全局配置
+func (s *ConfigSnapshotService) SetConfigSource(source func() *Config.Config) {
+	s.config = source
+}
+
+// CurrentValues 当前脱敏后的有效配置
+func (s *ConfigSnapshotService) CurrentValues() (map[string]interface{}, error) {
+	config := s.config()
+	if config == nil {
+		return nil, ErrConfigUnavailable
+	}
+	return normalizeConfigValues(config.RedactedValues())
+}
+
+// Capture 为当前配置生成快照
+func (s *ConfigSnapshotService) Capture(input ConfigSnapshotInput, createdBy uint) (*Models.ConfigSnapshot, error) {
+	values, err := s.CurrentValues()
+	if err != nil {
+		return nil, err
+	}
+	input.Host, _ = os.Hostname()
+	return s.save(input, values, ConfigSnapshotCapture, createdBy)
+}
+
+// CaptureIfChanged 当前配置与本环境上一个快照不同时生成快照，相同时返回 nil
+func (s *ConfigSnapshotService) CaptureIfChanged(label string) (*Models.ConfigSnapshot, error) {
+	values, err := s.CurrentValues()
+	if err != nil {
+		return nil, err
+	}
+	environment := s.environment("")
+	var latest Models.ConfigSnapshot
+	err = s.db.Where("environment = ?", environment).Order("version DESC").First(&latest).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err == nil && latest.Checksum == configChecksum(values) {
+		return nil, nil
+	}
+	host, _ := os.Hostname()
+	return s.save(ConfigSnapshotInput{Environment: environment, Host: host, Label: label}, values, ConfigSnapshotStartup, 0)
+}
+
+// Import 导入其他环境的快照，导入的配置再次脱敏
+func (s *ConfigSnapshotService) Import(input ConfigSnapshotInput, createdBy uint) (*Models.ConfigSnapshot, error) {
+	if len(input.Values) == 0 {
+		return nil, fmt.Errorf("%w: values 不能为空", ErrInvalidConfigSnapshot)
+	}
+	if strings.TrimSpace(input.Environment) == "" {
+		return nil, fmt.Errorf("%w: 导入时必须指定 environment", ErrInvalidConfigSnapshot)
+	}
+	values, err := normalizeConfigValues(Config.RedactConfigValues(input.Values))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfigSnapshot, err)
+	}
+	return s.save(input, values, ConfigSnapshotImport, createdBy)
+}
+
+// save 保存快照，版本号为本环境最大版本号加1
+func (s *ConfigSnapshotService) save(input ConfigSnapshotInput, values map[string]interface{}, source string, createdBy uint) (*Models.ConfigSnapshot, error) {
+	label := strings.TrimSpace(input.Label)
+	if len([]rune(label)) > 200 {
+		return nil, fmt.Errorf("%w: 说明不能超过200个字符", ErrInvalidConfigSnapshot)
+	}
+	environment := s.environment(input.Environment)
+	if len(environment) > 50 {
+		return nil, fmt.Errorf("%w: 环境名称不能超过50个字符", ErrInvalidConfigSnapshot)
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Models.ConfigSnapshot{
+		Environment: environment,
+		Host:        strings.TrimSpace(input.Host),
+		Label:       label,
+		Source:      source,
+		Checksum:    configChecksum(values),
+		KeyCount:    len(values),
+		Data:        string(data),
+		CreatedBy:   createdBy,
+		CreatedAt:   s.now(),
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var version int
+		if err := tx.Model(&Models.ConfigSnapshot{}).Where("environment = ?", environment).
+			Select("COALESCE(MAX(version), 0)").Scan(&version).Error; err != nil {
+			return err
+		}
+		snapshot.Version = version + 1
+		return tx.Create(snapshot).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// List 获取快照列表，不包含配置内容，environment 为空时返回全部环境
+func (s *ConfigSnapshotService) List(environment string, limit int) ([]Models.ConfigSnapshot, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	query := s.db.Omit("data").Order("id DESC").Limit(limit)
+	if environment != "" {
+		query = query.Where("environment = ?", environment)
+	}
+	snapshots := make([]Models.ConfigSnapshot, 0)
+	err := query.Find(&snapshots).Error
+	return snapshots, err
+}
+
+// Get 获取快照及其配置内容
+func (s *ConfigSnapshotService) Get(id uint) (*Models.ConfigSnapshot, error) {
+	var snapshot Models.ConfigSnapshot
+	err := s.db.First(&snapshot, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrConfigSnapshotNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(snapshot.Data), &snapshot.Values); err != nil {
+		return nil, fmt.Errorf("配置快照内容格式错误: %v", err)
+	}
+	return &snapshot, nil
+}
+
+// Delete 删除快照
+func (s *ConfigSnapshotService) Delete(id uint) error {
+	result := s.db.Delete(&Models.ConfigSnapshot{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrConfigSnapshotNotFound
+	}
+	return nil
+}
+
+// Diff 比较两个快照，toID 为0时与当前配置比较；ignore 中的键前缀不参与比较
+func (s *ConfigSnapshotService) Diff(fromID, toID uint, ignore []string) (*ConfigDiff, error) {
+	from, err := s.Get(fromID)
+	if err != nil {
+		return nil, err
+	}
+	diff := &ConfigDiff{From: snapshotRef(from), Ignored: ignore}
+
+	var toValues map[string]interface{}
+	if toID == 0 {
+		if toValues, err = s.CurrentValues(); err != nil {
+			return nil, err
+		}
+		diff.To = ConfigSnapshotRef{Environment: s.environment(""), Label: "current", Checksum: configChecksum(toValues), CreatedAt: s.now()}
+	} else {
+		to, err := s.Get(toID)
+		if err != nil {
+			return nil, err
+		}
+		toValues = to.Values
+		diff.To = snapshotRef(to)
+	}
+
+	diff.Changes = DiffConfigValues(from.Values, toValues, ignore)
+	for _, change := range diff.Changes {
+		switch change.Type {
+		case ConfigChangeAdded:
+			diff.Added++
+		case ConfigChangeRemoved:
+			diff.Removed++
+		case ConfigChangeChanged:
+			diff.Changed++
+		}
+	}
+	return diff, nil
+}
+
+// DiffConfigValues 比较两份展开后的配置，按键排序返回差异
+func DiffConfigValues(from, to map[string]interface{}, ignore []string) []ConfigChange {
+	keys := make(map[string]struct{}, len(from)+len(to))
+	for key := range from {
+		keys[key] = struct{}{}
+	}
+	for key := range to {
+		keys[key] = struct{}{}
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		if !ignoredConfigKey(key, ignore) {
+			sorted = append(sorted, key)
+		}
+	}
+	sort.Strings(sorted)
+
+	changes := make([]ConfigChange, 0)
+	for _, key := range sorted {
+		fromValue, inFrom := from[key]
+		toValue, inTo := to[key]
+		switch {
+		case !inFrom:
+			changes = append(changes, ConfigChange{Key: key, Type: ConfigChangeAdded, To: toValue})
+		case !inTo:
+			changes = append(changes, ConfigChange{Key: key, Type: ConfigChangeRemoved, From: fromValue})
+		case !configValueEqual(fromValue, toValue):
+			changes = append(changes, ConfigChange{Key: key, Type: ConfigChangeChanged, From: fromValue, To: toValue})
+		}
+	}
+	return changes
+}
+
+// environment 快照的环境，未指定时使用 APP_ENV
+func (s *ConfigSnapshotService) environment(environment string) string {
+	if environment = strings.TrimSpace(environment); environment != "" {
+		return environment
+	}
+	if config := s.config(); config != nil && config.Server.Environment != "" {
+		return config.Server.Environment
+	}
+	return "default"
+}
+
+// snapshotRef 快照摘要
+func snapshotRef(snapshot *Models.ConfigSnapshot) ConfigSnapshotRef {
+	return ConfigSnapshotRef{
+		ID:          snapshot.ID,
+		Environment: snapshot.Environment,
+		Version:     snapshot.Version,
+		Label:       snapshot.Label,
+		Checksum:    snapshot.Checksum,
+		CreatedAt:   snapshot.CreatedAt,
+	}
+}
+
+// ignoredConfigKey 键是否等于或位于忽略的前缀下，如 server 忽略 server.port
+func ignoredConfigKey(key string, ignore []string) bool {
+	for _, prefix := range ignore {
+		prefix = strings.TrimSuffix(strings.TrimSpace(prefix), ".")
+		if prefix != "" && (key == prefix || strings.HasPrefix(key, prefix+".")) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeConfigValues 经过一次JSON编解码，使当前配置与从数据库读取的快照中的数字类型一致
+func normalizeConfigValues(values map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	normalized := make(map[string]interface{}, len(values))
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// configChecksum 配置内容的SHA-256，JSON编码时map按键排序，结果稳定
+func configChecksum(values map[string]interface{}) string {
+	data, _ := json.Marshal(values)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// configValueEqual 按JSON编码比较配置值
+func configValueEqual(a, b interface{}) bool {
+	left, _ := json.Marshal(a)
+	right, _ := json.Marshal(b)
+	return string(left) == string(right)
+}
@@ -0,0 +1,148 @@
+package Services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule 五段式cron表达式：分 时 日 月 周
+// 功能说明：
+// 1. 每段支持 *、数字、范围 a-b、列表 a,b 和步长 */n、a-b/n
+// 2. 周取值0-7，0和7都表示周日
+// 3. 日和周同时限定时满足任一即可，与标准cron一致
+type CronSchedule struct {
+	minute     uint64
+	hour       uint64
+	dayOfMonth uint64
+	month      uint64
+	dayOfWeek  uint64
+	// 日和周是否以 * 开头，用于判断两者的组合方式
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+}
+
+// cronField cron字段的取值范围
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// ParseCronSchedule 解析五段式cron表达式
+func ParseCronSchedule(expression string) (*CronSchedule, error) {
+	parts := strings.Fields(expression)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("cron表达式需要5段（分 时 日 月 周），实际 %d 段", len(parts))
+	}
+
+	bits := make([]uint64, len(parts))
+	for i, part := range parts {
+		value, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, err
+		}
+		bits[i] = value
+	}
+	// 周日可以写作0或7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] = bits[4]&^(1<<7) | 1
+	}
+
+	return &CronSchedule{
+		minute:        bits[0],
+		hour:          bits[1],
+		dayOfMonth:    bits[2],
+		month:         bits[3],
+		dayOfWeek:     bits[4],
+		anyDayOfMonth: strings.HasPrefix(parts[2], "*"),
+		anyDayOfWeek:  strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+// parseCronField 解析单个字段，返回按位表示的取值集合
+func parseCronField(part string, field cronField) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(part, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			value, err := strconv.Atoi(stepPart)
+			if err != nil || value <= 0 {
+				return 0, fmt.Errorf("cron %s 字段步长 %q 无效", field.name, stepPart)
+			}
+			step = value
+		}
+
+		start, end := field.min, field.max
+		if rangePart != "*" {
+			low, high, isRange := strings.Cut(rangePart, "-")
+			value, err := strconv.Atoi(low)
+			if err != nil {
+				return 0, fmt.Errorf("cron %s 字段取值 %q 无效", field.name, item)
+			}
+			start, end = value, value
+			if isRange {
+				if end, err = strconv.Atoi(high); err != nil {
+					return 0, fmt.Errorf("cron %s 字段取值 %q 无效", field.name, item)
+				}
+			} else if hasStep {
+				// a/n 表示从a开始到最大值
+				end = field.max
+			}
+		}
+		if start < field.min || end > field.max || start > end {
+			return 0, fmt.Errorf("cron %s 字段取值 %q 超出范围 %d-%d", field.name, item, field.min, field.max)
+		}
+		for value := start; value <= end; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+// Next 返回晚于 after 的下一个执行时间，精确到分钟，使用 after 所在时区
+// 四年内没有匹配的时间（如2月30日）时返回零值
+func (s *CronSchedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(4, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// matchDay 日期是否匹配日和周字段
+func (s *CronSchedule) matchDay(t time.Time) bool {
+	dayOfMonth := s.dayOfMonth&(1<<uint(t.Day())) != 0
+	dayOfWeek := s.dayOfWeek&(1<<uint(t.Weekday())) != 0
+	if s.anyDayOfMonth || s.anyDayOfWeek {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
@@ -0,0 +1,736 @@
+package Services
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Utils"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 可导出的数据集
+const (
+	ExportDatasetAlerts         = "alerts"
+	ExportDatasetLoginAttempts  = "login_attempts"
+	ExportDatasetSecurityEvents = "security_events"
+)
+
+// 定时导出运行结果
+const (
+	ExportRunSuccess = "success"
+	ExportRunFailed  = "failed"
+)
+
+var (
+	// ErrUnknownExportDataset 不支持的导出数据集
+	ErrUnknownExportDataset = errors.New("不支持的导出数据集，支持 alerts、login_attempts、security_events")
+	// ErrExportScheduleNotFound 定时导出不存在
+	ErrExportScheduleNotFound = errors.New("定时导出不存在")
+	// ErrInvalidExportSchedule 定时导出参数无效
+	ErrInvalidExportSchedule = errors.New("定时导出参数无效")
+)
+
+// AlertQuerySpec 监控告警列表和导出可筛选和返回的字段，告警固定按创建时间倒序
+var AlertQuerySpec = Utils.QuerySpec{
+	Fields: map[string]Utils.QueryField{
+		"id":          {Type: Utils.QueryString, Filter: true},
+		"rule_id":     {Type: Utils.QueryString, Filter: true},
+		"fingerprint": {Type: Utils.QueryString, Filter: true},
+		"level":       {Type: Utils.QueryString, Filter: true},
+		"message":     {Type: Utils.QueryString, Filter: true},
+		"metric":      {Type: Utils.QueryString, Filter: true},
+		"value":       {Type: Utils.QueryFloat, Filter: true},
+		"threshold":   {Type: Utils.QueryFloat, Filter: true},
+		"status":      {Type: Utils.QueryString, Filter: true},
+		"count":       {Type: Utils.QueryInt, Filter: true},
+		"flapping":    {Type: Utils.QueryBool, Filter: true},
+		"last_seen":   {Type: Utils.QueryTime, Filter: true},
+		"created_at":  {Type: Utils.QueryTime, Filter: true},
+		"resolved_at": {Type: Utils.QueryTime, Filter: true},
+	},
+}
+
+// SecurityEventQuerySpec 安全事件列表和导出可筛选、排序和返回的字段
+var SecurityEventQuerySpec = Utils.QuerySpec{
+	Fields: map[string]Utils.QueryField{
+		"id":            {Column: "id", Type: Utils.QueryInt, Filter: true, Sort: true},
+		"event_type":    {Column: "event_type", Type: Utils.QueryString, Filter: true},
+		"event_level":   {Column: "event_level", Type: Utils.QueryString, Filter: true},
+		"user_id":       {Column: "user_id", Type: Utils.QueryInt, Filter: true},
+		"username":      {Column: "username", Type: Utils.QueryString, Filter: true},
+		"ip_address":    {Column: "ip_address", Type: Utils.QueryString, Filter: true},
+		"resource":      {Column: "resource", Type: Utils.QueryString, Filter: true},
+		"action":        {Column: "action", Type: Utils.QueryString, Filter: true},
+		"details":       {Column: "details", Type: Utils.QueryString},
+		"risk_score":    {Column: "risk_score", Type: Utils.QueryFloat, Filter: true, Sort: true},
+		"anomaly_score": {Column: "anomaly_score", Type: Utils.QueryFloat, Filter: true, Sort: true},
+		"blocked":       {Column: "blocked", Type: Utils.QueryBool, Filter: true},
+		"alerted":       {Column: "alerted", Type: Utils.QueryBool, Filter: true},
+		"location":      {Column: "location", Type: Utils.QueryString, Filter: true},
+		"session_id":    {Column: "session_id", Type: Utils.QueryString, Filter: true},
+		"created_at":    {Column: "created_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+	},
+	DefaultSort: "-created_at",
+}
+
+// LoginAttemptQuerySpec 登录尝试列表和导出可筛选、排序和返回的字段
+var LoginAttemptQuerySpec = Utils.QuerySpec{
+	Fields: map[string]Utils.QueryField{
+		"id":             {Column: "id", Type: Utils.QueryInt, Filter: true, Sort: true},
+		"username":       {Column: "username", Type: Utils.QueryString, Filter: true},
+		"ip_address":     {Column: "ip_address", Type: Utils.QueryString, Filter: true},
+		"user_agent":     {Column: "user_agent", Type: Utils.QueryString},
+		"success":        {Column: "success", Type: Utils.QueryBool, Filter: true},
+		"failure_reason": {Column: "failure_reason", Type: Utils.QueryString, Filter: true},
+		"attempt_time":   {Column: "attempt_time", Type: Utils.QueryTime, Filter: true, Sort: true},
+		"location":       {Column: "location", Type: Utils.QueryString, Filter: true},
+		"device_info":    {Column: "device_info", Type: Utils.QueryString},
+		"risk_score":     {Column: "risk_score", Type: Utils.QueryFloat, Filter: true, Sort: true},
+		"blocked":        {Column: "blocked", Type: Utils.QueryBool, Filter: true},
+		"created_at":     {Column: "created_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+	},
+	DefaultSort: "-attempt_time",
+}
+
+// exportDatasetSpecs 各数据集的查询字段
+var exportDatasetSpecs = map[string]Utils.QuerySpec{
+	ExportDatasetAlerts:         AlertQuerySpec,
+	ExportDatasetLoginAttempts:  LoginAttemptQuerySpec,
+	ExportDatasetSecurityEvents: SecurityEventQuerySpec,
+}
+
+// exportColumn 导出的列，Name 与列表接口的字段名相同
+type exportColumn[T any] struct {
+	Name  string
+	Value func(record *T) interface{}
+}
+
+var securityEventExportColumns = []exportColumn[Models.SecurityEvent]{
+	{"id", func(e *Models.SecurityEvent) interface{} { return e.ID }},
+	{"created_at", func(e *Models.SecurityEvent) interface{} { return e.CreatedAt }},
+	{"event_type", func(e *Models.SecurityEvent) interface{} { return e.EventType }},
+	{"event_level", func(e *Models.SecurityEvent) interface{} { return e.EventLevel }},
+	{"user_id", func(e *Models.SecurityEvent) interface{} { return e.UserID }},
+	{"username", func(e *Models.SecurityEvent) interface{} { return e.Username }},
+	{"ip_address", func(e *Models.SecurityEvent) interface{} { return e.IPAddress }},
+	{"resource", func(e *Models.SecurityEvent) interface{} { return e.Resource }},
+	{"action", func(e *Models.SecurityEvent) interface{} { return e.Action }},
+	{"details", func(e *Models.SecurityEvent) interface{} { return e.Details }},
+	{"risk_score", func(e *Models.SecurityEvent) interface{} { return e.RiskScore }},
+	{"anomaly_score", func(e *Models.SecurityEvent) interface{} { return e.AnomalyScore }},
+	{"blocked", func(e *Models.SecurityEvent) interface{} { return e.Blocked }},
+	{"alerted", func(e *Models.SecurityEvent) interface{} { return e.Alerted }},
+	{"location", func(e *Models.SecurityEvent) interface{} { return e.Location }},
+	{"session_id", func(e *Models.SecurityEvent) interface{} { return e.SessionID }},
+}
+
+var loginAttemptExportColumns = []exportColumn[Models.LoginAttempt]{
+	{"id", func(a *Models.LoginAttempt) interface{} { return a.ID }},
+	{"attempt_time", func(a *Models.LoginAttempt) interface{} { return a.AttemptTime }},
+	{"username", func(a *Models.LoginAttempt) interface{} { return a.Username }},
+	{"ip_address", func(a *Models.LoginAttempt) interface{} { return a.IPAddress }},
+	{"success", func(a *Models.LoginAttempt) interface{} { return a.Success }},
+	{"failure_reason", func(a *Models.LoginAttempt) interface{} { return a.FailureReason }},
+	{"location", func(a *Models.LoginAttempt) interface{} { return a.Location }},
+	{"user_agent", func(a *Models.LoginAttempt) interface{} { return a.UserAgent }},
+	{"device_info", func(a *Models.LoginAttempt) interface{} { return a.DeviceInfo }},
+	{"risk_score", func(a *Models.LoginAttempt) interface{} { return a.RiskScore }},
+	{"blocked", func(a *Models.LoginAttempt) interface{} { return a.Blocked }},
+	{"created_at", func(a *Models.LoginAttempt) interface{} { return a.CreatedAt }},
+}
+
+var alertExportColumns = []exportColumn[Alert]{
+	{"id", func(a *Alert) interface{} { return a.ID }},
+	{"created_at", func(a *Alert) interface{} { return a.CreatedAt }},
+	{"rule_id", func(a *Alert) interface{} { return a.RuleID }},
+	{"fingerprint", func(a *Alert) interface{} { return a.Fingerprint }},
+	{"level", func(a *Alert) interface{} { return string(a.Level) }},
+	{"status", func(a *Alert) interface{} { return a.Status }},
+	{"message", func(a *Alert) interface{} { return a.Message }},
+	{"metric", func(a *Alert) interface{} { return a.Metric }},
+	{"value", func(a *Alert) interface{} { return a.Value }},
+	{"threshold", func(a *Alert) interface{} { return a.Threshold }},
+	{"count", func(a *Alert) interface{} { return a.Count }},
+	{"flapping", func(a *Alert) interface{} { return a.Flapping }},
+	{"last_seen", func(a *Alert) interface{} { return a.LastSeen }},
+	{"resolved_at", func(a *Alert) interface{} { return a.ResolvedAt }},
+}
+
+// DataExportRequest 导出条件
+type DataExportRequest struct {
+	Dataset  string
+	Format   string
+	Query    *Utils.ListQuery // 与列表接口相同的筛选、排序和字段，为空时导出全部字段
+	Status   string           // 告警状态，只用于告警
+	Severity string           // 告警级别，只用于告警
+	Since    time.Time        // 只导出该时间之后的数据，零值表示不限制
+	MaxRows  int              // 最多导出的行数，0表示不限制
+	Visible  func(*Alert) bool // 告警是否对当前用户可见，只用于告警，为空时导出全部告警
+}
+
+// DataExportResult 导出结果
+type DataExportResult struct {
+	Rows      int  `json:"rows"`
+	Truncated bool `json:"truncated"` // 超过最大行数被截断
+}
+
+// ExportScheduleInput 创建和更新定时导出的参数
+type ExportScheduleInput struct {
+	Name       string   `json:"name"`
+	Dataset    string   `json:"dataset"`
+	Format     string   `json:"format"`
+	Query      string   `json:"query"`
+	Since      string   `json:"since"`
+	Schedule   string   `json:"schedule"`
+	Recipients []string `json:"recipients"`
+	Enabled    *bool    `json:"enabled"`
+}
+
+// DataExportService 数据导出服务
+// 功能说明：
+// 1. 告警、登录尝试和安全事件按列表接口的筛选、排序和字段参数导出为CSV或XLSX
+// 2. 数据库记录逐行读取并写入，不在内存中保存整个结果集；告警导出附带每条告警的评论记录
+// 3. 定时导出按cron表达式运行，结果作为邮件附件发送，多实例部署时以运行次数抢占，只有一个实例执行
+type DataExportService struct {
+	db       *gorm.DB
+	config   *Config.ExportConfig
+	core     *MonitoringCore
+	comments *CommentService
+	mail     *MailService
+	now      func() time.Time
+}
+
+// NewDataExportService 创建数据导出服务
+func NewDataExportService(db *gorm.DB, config *Config.ExportConfig) *DataExportService {
+	if config == nil {
+		config = Config.GetExportConfig()
+	}
+	if config == nil {
+		config = &Config.ExportConfig{MaxRows: 1000000, ScheduleEnabled: true, CheckInterval: time.Minute, MaxAttachmentRows: 50000}
+	}
+	return &DataExportService{
+		db:     db,
+		config: config,
+		core:   DefaultMonitoringCore(),
+		mail:   DefaultMailService(),
+		now:    time.Now,
+	}
+}
+
+// SetMonitoringCore 设置导出告警使用的监控核心
+func (s *DataExportService) SetMonitoringCore(core *MonitoringCore) {
+	s.core = core
+}
+
+// SetCommentService 设置评论服务，设置后告警导出附带评论记录
+func (s *DataExportService) SetCommentService(comments *CommentService) {
+	s.comments = comments
+}
+
+// SetMailService 设置发送定时导出邮件的邮件服务
+func (s *DataExportService) SetMailService(mailService *MailService) {
+	s.mail = mailService
+}
+
+// MaxRows 单次下载最多导出的行数
+func (s *DataExportService) MaxRows() int {
+	return s.config.MaxRows
+}
+
+// ParseExportQuery 按数据集的查询字段解析筛选、排序和字段参数
+func ParseExportQuery(dataset string, values url.Values) (*Utils.ListQuery, error) {
+	spec, ok := exportDatasetSpecs[dataset]
+	if !ok {
+		return nil, ErrUnknownExportDataset
+	}
+	return Utils.ParseListQuery(values, spec)
+}
+
+// ExportFileName 导出文件名，如 security_events-20240101-080000.xlsx
+func ExportFileName(dataset, format string, at time.Time) string {
+	return fmt.Sprintf("%s-%s.%s", dataset, at.Format("20060102-150405"), format)
+}
+
+// Export 按条件导出数据并写入 w
+func (s *DataExportService) Export(ctx context.Context, w io.Writer, req DataExportRequest) (DataExportResult, error) {
+	if !IsExportFormat(req.Format) {
+		return DataExportResult{}, ErrUnsupportedExportFormat
+	}
+	if _, ok := exportDatasetSpecs[req.Dataset]; !ok {
+		return DataExportResult{}, ErrUnknownExportDataset
+	}
+	if req.Query == nil {
+		q, err := ParseExportQuery(req.Dataset, url.Values{})
+		if err != nil {
+			return DataExportResult{}, err
+		}
+		req.Query = q
+	}
+
+	writer, err := NewTabularWriter(w, req.Format, req.Dataset)
+	if err != nil {
+		return DataExportResult{}, err
+	}
+	var result DataExportResult
+	switch req.Dataset {
+	case ExportDatasetAlerts:
+		result, err = s.exportAlerts(writer, req)
+	case ExportDatasetLoginAttempts:
+		result, err = exportRecords(ctx, s.db, writer, req, "attempt_time", loginAttemptExportColumns)
+	case ExportDatasetSecurityEvents:
+		result, err = exportRecords(ctx, s.db, writer, req, "created_at", securityEventExportColumns)
+	}
+	if err != nil {
+		return result, err
+	}
+	return result, writer.Close()
+}
+
+// exportRecords 逐行读取数据库记录并写入表格
+func exportRecords[T any](ctx context.Context, db *gorm.DB, writer TabularWriter, req DataExportRequest, timeColumn string, columns []exportColumn[T]) (DataExportResult, error) {
+	var result DataExportResult
+	columns = selectExportColumns(columns, req.Query.Fields)
+	if err := writeExportHeader(writer, columns, ""); err != nil {
+		return result, err
+	}
+
+	query := db.WithContext(ctx).Model(new(T))
+	if !req.Since.IsZero() {
+		query = query.Where(clause.Expr{SQL: "? >= ?", Vars: []interface{}{clause.Column{Name: timeColumn}, req.Since}})
+	}
+	query, order, err := req.Query.Apply(query, Utils.PageRequest{})
+	if err != nil {
+		return result, err
+	}
+	// 按单个时间字段或id排序时 Apply 不添加排序，在这里补上；最后按主键排序保证结果稳定
+	if order.Column != "" {
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: order.Column}, Desc: !order.Asc})
+	}
+	query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: !order.Asc})
+
+	rows, err := query.Rows()
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+
+	values := make([]interface{}, len(columns))
+	for rows.Next() {
+		if req.MaxRows > 0 && result.Rows >= req.MaxRows {
+			result.Truncated = true
+			break
+		}
+		var record T
+		if err := query.ScanRows(rows, &record); err != nil {
+			return result, err
+		}
+		for i, column := range columns {
+			values[i] = column.Value(&record)
+		}
+		if err := writer.WriteRow(values); err != nil {
+			return result, err
+		}
+		result.Rows++
+	}
+	return result, rows.Err()
+}
+
+// exportAlerts 导出监控核心中的告警，筛选与告警列表接口相同，按创建时间倒序
+func (s *DataExportService) exportAlerts(writer TabularWriter, req DataExportRequest) (DataExportResult, error) {
+	var result DataExportResult
+	columns := selectExportColumns(alertExportColumns, req.Query.Fields)
+	if err := writeExportHeader(writer, columns, "comments"); err != nil {
+		return result, err
+	}
+	if s.core == nil {
+		return result, nil
+	}
+
+	alerts := filterAlerts(s.core.Alerts(req.Status, 0), req.Visible)
+	sort.SliceStable(alerts, func(i, j int) bool {
+		if !alerts[i].CreatedAt.Equal(alerts[j].CreatedAt) {
+			return alerts[i].CreatedAt.After(alerts[j].CreatedAt)
+		}
+		return alerts[i].ID > alerts[j].ID
+	})
+	records := alertsToInterfaces(alerts, req.Severity)
+	if len(req.Query.Filters) > 0 {
+		indexes, err := req.Query.FilterRecords(records)
+		if err != nil {
+			return result, err
+		}
+		filtered := make([]interface{}, 0, len(indexes))
+		for _, i := range indexes {
+			filtered = append(filtered, records[i])
+		}
+		records = filtered
+	}
+
+	selected := make([]*Alert, 0, len(records))
+	for _, record := range records {
+		alert := record.(*Alert)
+		if !req.Since.IsZero() && alert.CreatedAt.Before(req.Since) {
+			continue
+		}
+		if req.MaxRows > 0 && len(selected) >= req.MaxRows {
+			result.Truncated = true
+			break
+		}
+		selected = append(selected, alert)
+	}
+
+	trails, err := s.alertCommentTrails(selected)
+	if err != nil {
+		return result, err
+	}
+	values := make([]interface{}, len(columns)+1)
+	for _, alert := range selected {
+		for i, column := range columns {
+			values[i] = column.Value(alert)
+		}
+		values[len(columns)] = trails[alert.ID]
+		if err := writer.WriteRow(values); err != nil {
+			return result, err
+		}
+		result.Rows++
+	}
+	return result, nil
+}
+
+// alertCommentTrails 按告警汇总评论，每条评论一行：时间 用户: 内容
+func (s *DataExportService) alertCommentTrails(alerts []*Alert) (map[string]string, error) {
+	trails := make(map[string]string)
+	if s.comments == nil || len(alerts) == 0 {
+		return trails, nil
+	}
+	ids := make([]string, 0, len(alerts))
+	for _, alert := range alerts {
+		ids = append(ids, alert.ID)
+	}
+	comments, err := s.comments.Trail(Models.CommentResourceAlert, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, comment := range comments {
+		line := fmt.Sprintf("%s %s: %s", comment.CreatedAt.Format("2006-01-02 15:04"), comment.Username, comment.Content)
+		if trails[comment.ResourceID] != "" {
+			line = trails[comment.ResourceID] + "\n" + line
+		}
+		trails[comment.ResourceID] = line
+	}
+	return trails, nil
+}
+
+// selectExportColumns 指定了返回字段时只导出这些列，按指定的顺序
+func selectExportColumns[T any](columns []exportColumn[T], fields []string) []exportColumn[T] {
+	if len(fields) == 0 {
+		return columns
+	}
+	selected := make([]exportColumn[T], 0, len(fields))
+	for _, field := range fields {
+		for _, column := range columns {
+			if column.Name == field {
+				selected = append(selected, column)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// writeExportHeader 写入表头，extra 不为空时追加在最后
+func writeExportHeader[T any](writer TabularWriter, columns []exportColumn[T], extra string) error {
+	header := make([]interface{}, 0, len(columns)+1)
+	for _, column := range columns {
+		header = append(header, column.Name)
+	}
+	if extra != "" {
+		header = append(header, extra)
+	}
+	return writer.WriteRow(header)
+}
+
+// ListSchedules 获取全部定时导出
+func (s *DataExportService) ListSchedules() ([]Models.ExportSchedule, error) {
+	schedules := make([]Models.ExportSchedule, 0)
+	err := s.db.Order("id ASC").Find(&schedules).Error
+	return schedules, err
+}
+
+// GetSchedule 获取定时导出
+func (s *DataExportService) GetSchedule(id uint) (*Models.ExportSchedule, error) {
+	var schedule Models.ExportSchedule
+	err := s.db.First(&schedule, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrExportScheduleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// CreateSchedule 创建定时导出
+func (s *DataExportService) CreateSchedule(input ExportScheduleInput, createdBy uint) (*Models.ExportSchedule, error) {
+	schedule := &Models.ExportSchedule{CreatedBy: createdBy, Enabled: true}
+	if err := s.applyScheduleInput(schedule, input); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(schedule).Error; err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// UpdateSchedule 更新定时导出，调度表达式变化时重新计算下次运行时间
+func (s *DataExportService) UpdateSchedule(id uint, input ExportScheduleInput) (*Models.ExportSchedule, error) {
+	schedule, err := s.GetSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyScheduleInput(schedule, input); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(schedule).Error; err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// DeleteSchedule 删除定时导出
+func (s *DataExportService) DeleteSchedule(id uint) error {
+	result := s.db.Delete(&Models.ExportSchedule{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrExportScheduleNotFound
+	}
+	return nil
+}
+
+// applyScheduleInput 校验参数并写入定时导出
+func (s *DataExportService) applyScheduleInput(schedule *Models.ExportSchedule, input ExportScheduleInput) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len([]rune(name)) > 100 {
+		return fmt.Errorf("%w: 名称不能为空且不能超过100个字符", ErrInvalidExportSchedule)
+	}
+	format := strings.ToLower(strings.TrimSpace(input.Format))
+	if format == "" {
+		format = ExportFormatCSV
+	}
+	if !IsExportFormat(format) {
+		return fmt.Errorf("%w: %v", ErrInvalidExportSchedule, ErrUnsupportedExportFormat)
+	}
+	query := strings.TrimPrefix(strings.TrimSpace(input.Query), "?")
+	if _, _, err := s.scheduleQuery(input.Dataset, query); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidExportSchedule, err)
+	}
+	since := strings.TrimSpace(input.Since)
+	if since != "" {
+		if d, err := time.ParseDuration(since); err != nil || d <= 0 {
+			return fmt.Errorf("%w: since 应为正的时长，如 24h", ErrInvalidExportSchedule)
+		}
+	}
+	expression := strings.TrimSpace(input.Schedule)
+	cron, err := ParseCronSchedule(expression)
+	if err != nil {
+		return fmt.Errorf("%w: 调度表达式无效: %v", ErrInvalidExportSchedule, err)
+	}
+	if len(input.Recipients) > 50 {
+		return fmt.Errorf("%w: 接收邮箱不能超过50个", ErrInvalidExportSchedule)
+	}
+	recipients := make([]string, 0, len(input.Recipients))
+	for _, recipient := range input.Recipients {
+		address, err := mail.ParseAddress(strings.TrimSpace(recipient))
+		if err != nil {
+			return fmt.Errorf("%w: 无效的邮箱 %s", ErrInvalidExportSchedule, recipient)
+		}
+		recipients = append(recipients, address.Address)
+	}
+	if len(recipients) == 0 {
+		return fmt.Errorf("%w: 至少需要一个接收邮箱", ErrInvalidExportSchedule)
+	}
+
+	if schedule.Schedule != expression || schedule.NextRunAt == nil {
+		next := cron.Next(s.now())
+		schedule.NextRunAt = &next
+	}
+	schedule.Name = name
+	schedule.Dataset = input.Dataset
+	schedule.Format = format
+	schedule.Query = query
+	schedule.Since = since
+	schedule.Schedule = expression
+	schedule.Recipients = strings.Join(recipients, ",")
+	if input.Enabled != nil {
+		schedule.Enabled = *input.Enabled
+	}
+	return nil
+}
+
+// scheduleQuery 解析定时导出保存的查询字符串，返回列表查询和告警的状态、级别参数
+func (s *DataExportService) scheduleQuery(dataset, rawQuery string) (*Utils.ListQuery, url.Values, error) {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, nil, fmt.Errorf("查询字符串格式错误: %v", err)
+	}
+	q, err := ParseExportQuery(dataset, values)
+	if err != nil {
+		return nil, nil, err
+	}
+	return q, values, nil
+}
+
+// RunSchedule 立即运行定时导出并通过邮件发送结果
+func (s *DataExportService) RunSchedule(ctx context.Context, id uint) (*Models.ExportSchedule, error) {
+	schedule, err := s.GetSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+	s.run(ctx, schedule, s.now())
+	return schedule, nil
+}
+
+// RunDueSchedules 运行到期的定时导出，返回运行的数量
+func (s *DataExportService) RunDueSchedules(ctx context.Context, now time.Time) int {
+	var schedules []Models.ExportSchedule
+	if err := s.db.Where("enabled = ? AND next_run_at <= ?", true, now).Find(&schedules).Error; err != nil {
+		log.Printf("查询到期的定时导出失败: %v", err)
+		return 0
+	}
+
+	ran := 0
+	for i := range schedules {
+		schedule := &schedules[i]
+		cron, err := ParseCronSchedule(schedule.Schedule)
+		if err != nil {
+			log.Printf("定时导出调度表达式无效: schedule=%d, expression=%s, error=%v", schedule.ID, schedule.Schedule, err)
+			continue
+		}
+
+		// 错过的调度只补运行一次，下次运行时间从当前时间起算
+		// 以运行次数作为版本号抢占，多实例部署时只有一个实例运行
+		next := cron.Next(now)
+		claim := s.db.Model(&Models.ExportSchedule{}).
+			Where("id = ? AND run_count = ?", schedule.ID, schedule.RunCount).
+			Updates(map[string]interface{}{"next_run_at": next, "run_count": gorm.Expr("run_count + 1")})
+		if claim.Error != nil || claim.RowsAffected == 0 {
+			continue
+		}
+		schedule.NextRunAt = &next
+		schedule.RunCount++
+		s.run(ctx, schedule, now)
+		ran++
+	}
+	return ran
+}
+
+// StartScheduler 启动定时导出，按检查间隔运行到期的导出，ctx 取消时停止
+func (s *DataExportService) StartScheduler(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.config.CheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.RunDueSchedules(ctx, now)
+			}
+		}
+	}()
+}
+
+// run 生成导出文件并发送邮件，记录运行结果
+func (s *DataExportService) run(ctx context.Context, schedule *Models.ExportSchedule, now time.Time) {
+	result, err := s.deliver(ctx, schedule, now)
+	schedule.LastRunAt = &now
+	schedule.LastRows = result.Rows
+	schedule.LastStatus = ExportRunSuccess
+	schedule.LastError = ""
+	if err != nil {
+		schedule.LastStatus = ExportRunFailed
+		schedule.LastError = err.Error()
+		if len(schedule.LastError) > 1000 {
+			schedule.LastError = schedule.LastError[:1000]
+		}
+		log.Printf("定时导出失败: schedule=%d, error=%v", schedule.ID, err)
+	}
+	err = s.db.Model(schedule).Updates(map[string]interface{}{
+		"last_run_at": now,
+		"last_rows":   schedule.LastRows,
+		"last_status": schedule.LastStatus,
+		"last_error":  schedule.LastError,
+	}).Error
+	if err != nil {
+		log.Printf("保存定时导出结果失败: schedule=%d, error=%v", schedule.ID, err)
+	}
+}
+
+// deliver 生成导出文件并作为邮件附件发送
+func (s *DataExportService) deliver(ctx context.Context, schedule *Models.ExportSchedule, now time.Time) (DataExportResult, error) {
+	if s.mail == nil {
+		return DataExportResult{}, fmt.Errorf("邮件服务未配置")
+	}
+	q, values, err := s.scheduleQuery(schedule.Dataset, schedule.Query)
+	if err != nil {
+		return DataExportResult{}, err
+	}
+	req := DataExportRequest{
+		Dataset:  schedule.Dataset,
+		Format:   schedule.Format,
+		Query:    q,
+		Status:   values.Get("status"),
+		Severity: values.Get("severity"),
+		MaxRows:  s.config.MaxAttachmentRows,
+	}
+	if schedule.Since != "" {
+		if d, err := time.ParseDuration(schedule.Since); err == nil {
+			req.Since = now.Add(-d)
+		}
+	}
+
+	var buf bytes.Buffer
+	result, err := s.Export(ctx, &buf, req)
+	if err != nil {
+		return result, err
+	}
+
+	text := fmt.Sprintf("定时导出「%s」已生成，共 %d 行，见附件。\n数据集: %s\n筛选条件: %s\n",
+		schedule.Name, result.Rows, schedule.Dataset, displayExportQuery(schedule.Query))
+	if result.Truncated {
+		text += fmt.Sprintf("结果超过 %d 行，附件只包含前 %d 行，请缩小筛选范围或通过导出接口下载完整数据。\n", s.config.MaxAttachmentRows, result.Rows)
+	}
+	message := &Mail{
+		To:      strings.Split(schedule.Recipients, ","),
+		Subject: fmt.Sprintf("[定时导出] %s（%s）", schedule.Name, now.Format("2006-01-02 15:04")),
+		Text:    text,
+	}
+	message.Attach(ExportFileName(schedule.Dataset, schedule.Format, now), ExportContentType(schedule.Format), buf.Bytes())
+	_, err = s.mail.Queue(message)
+	return result, err
+}
+
+// displayExportQuery 邮件中显示的筛选条件
+func displayExportQuery(query string) string {
+	if query == "" {
+		return "无"
+	}
+	if decoded, err := url.QueryUnescape(query); err == nil {
+		return decoded
+	}
+	return query
+}
@@ -0,0 +1,497 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DebugRequestAdminPath 请求调试记录管理接口路径
+const DebugRequestAdminPath = "/api/v1/admin/debug/requests"
+
+// DebugRecorderKey 调试记录器在 gin.Context 和请求上下文中的键，与请求ID一样使用字符串键
+const DebugRecorderKey = "debug_recorder"
+
+// debugMaxStatementSize 单条SQL、缓存键和URL记录的最大长度
+const debugMaxStatementSize = 4096
+
+// 缓存操作的存储
+const (
+	DebugCacheStoreRedis      = "redis"
+	DebugCacheStoreModelCache = "model_cache"
+)
+
+// DebugQuery 请求执行的一条SQL
+type DebugQuery struct {
+	SQL        string  `json:"sql"` // SQL_LOG_INCLUDE_PARAMS=false 时为带占位符的语句
+	Model      string  `json:"model,omitempty"`
+	Table      string  `json:"table,omitempty"`
+	Operation  string  `json:"operation"`
+	Rows       int64   `json:"rows"`
+	DurationMs float64 `json:"duration_ms"`
+	OffsetMs   float64 `json:"offset_ms"` // 相对请求开始的时间
+	Error      string  `json:"error,omitempty"`
+}
+
+// DebugCacheOperation 请求执行的一次缓存操作
+type DebugCacheOperation struct {
+	Store      string  `json:"store"` // redis 或 model_cache
+	Operation  string  `json:"operation"`
+	Key        string  `json:"key,omitempty"`
+	Hit        *bool   `json:"hit,omitempty"`      // 只有读取操作有命中结果
+	Pipeline   bool    `json:"pipeline,omitempty"` // 管道中的命令，耗时为整个管道的耗时
+	DurationMs float64 `json:"duration_ms"`
+	OffsetMs   float64 `json:"offset_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// DebugHTTPCall 请求发出的一次出站HTTP调用，重试的每次尝试单独记录
+type DebugHTTPCall struct {
+	Dependency string  `json:"dependency"`
+	Method     string  `json:"method"`
+	URL        string  `json:"url"` // 不包含查询参数和用户信息
+	Attempt    int     `json:"attempt"`
+	StatusCode int     `json:"status_code,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+	OffsetMs   float64 `json:"offset_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// DebugNotification 请求发送的一条邮件或短信通知
+type DebugNotification struct {
+	Channel    string  `json:"channel"`            // mail 或 sms
+	Recipient  string  `json:"recipient"`          // 短信为脱敏后的手机号
+	Subject    string  `json:"subject,omitempty"`  // 邮件主题
+	Template   string  `json:"template,omitempty"` // 短信模板
+	Status     string  `json:"status"`
+	DurationMs float64 `json:"duration_ms"`
+	OffsetMs   float64 `json:"offset_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// DebugRequestSummary 调试记录摘要
+type DebugRequestSummary struct {
+	RequestID         string    `json:"request_id"`
+	Method            string    `json:"method"`
+	Path              string    `json:"path"`
+	Route             string    `json:"route,omitempty"`
+	StatusCode        int       `json:"status_code"`
+	UserID            string    `json:"user_id,omitempty"`
+	StartedAt         time.Time `json:"started_at"`
+	DurationMs        float64   `json:"duration_ms"`
+	Queries           int       `json:"queries"`
+	QueryMs           float64   `json:"query_ms"`
+	QueryErrors       int       `json:"query_errors"`
+	CacheOps          int       `json:"cache_ops"`
+	CacheHits         int       `json:"cache_hits"`
+	CacheMisses       int       `json:"cache_misses"`
+	HTTPCalls         int       `json:"http_calls"`
+	HTTPMs            float64   `json:"http_ms"`
+	NotificationCount int       `json:"notification_count"`
+	Dropped           int       `json:"dropped"` // 超过单个请求记录上限未记录的操作数
+}
+
+// DebugRequest 一个请求的调试记录
+type DebugRequest struct {
+	DebugRequestSummary
+	SQL           []DebugQuery          `json:"sql"`
+	Cache         []DebugCacheOperation `json:"cache"`
+	HTTP          []DebugHTTPCall       `json:"http"`
+	Notifications []DebugNotification   `json:"notifications"`
+}
+
+// DebugRecorder 单个请求的调试记录器
+// 功能说明：
+//  1. 调试模式中间件为请求创建记录器并放入请求上下文
+//  2. GORM日志适配器、Redis客户端钩子、模型缓存和出站HTTP客户端从上下文取得记录器，记录SQL、缓存操作和出站调用
+//     邮件和短信服务带上下文发送时记录发送的通知
+//  3. 记录器为 nil 时各记录方法不做任何事，调用方不需要判断
+//
+// 注意事项：
+// - 只能记录传入了请求上下文的操作，查询需要使用 db.WithContext(ctx)，Redis命令需要传入请求的 ctx
+// - 进程内的 CacheService 没有上下文参数，不会被记录
+type DebugRecorder struct {
+	mu         sync.Mutex
+	start      time.Time
+	maxEntries int
+	entries    int
+	request    DebugRequest
+}
+
+// NewDebugRecorder 创建请求的调试记录器，maxEntries 为单个请求最多记录的操作数
+func NewDebugRecorder(requestID, method, path string, maxEntries int) *DebugRecorder {
+	start := time.Now()
+	return &DebugRecorder{
+		start:      start,
+		maxEntries: maxEntries,
+		request: DebugRequest{
+			DebugRequestSummary: DebugRequestSummary{
+				RequestID: requestID,
+				Method:    method,
+				Path:      path,
+				StartedAt: start,
+			},
+			SQL:           []DebugQuery{},
+			Cache:         []DebugCacheOperation{},
+			HTTP:          []DebugHTTPCall{},
+			Notifications: []DebugNotification{},
+		},
+	}
+}
+
+// WithDebugRecorder 把调试记录器保存到 ctx 中
+func WithDebugRecorder(ctx context.Context, recorder *DebugRecorder) context.Context {
+	return context.WithValue(ctx, DebugRecorderKey, recorder)
+}
+
+// DebugRecorderFromContext 获取 ctx 中的调试记录器，不存在时返回 nil
+func DebugRecorderFromContext(ctx context.Context) *DebugRecorder {
+	if ctx == nil {
+		return nil
+	}
+	recorder, _ := ctx.Value(DebugRecorderKey).(*DebugRecorder)
+	return recorder
+}
+
+// RecordQuery 记录一条SQL
+func (r *DebugRecorder) RecordQuery(query DebugQuery, begin time.Time) {
+	if r == nil {
+		return
+	}
+	query.SQL = truncateDebugValue(query.SQL)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.reserveLocked() {
+		return
+	}
+	query.OffsetMs = debugMs(begin.Sub(r.start))
+	r.request.SQL = append(r.request.SQL, query)
+}
+
+// RecordCache 记录一次缓存操作
+func (r *DebugRecorder) RecordCache(op DebugCacheOperation, begin time.Time) {
+	if r == nil {
+		return
+	}
+	op.Key = truncateDebugValue(op.Key)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.reserveLocked() {
+		return
+	}
+	op.OffsetMs = debugMs(begin.Sub(r.start))
+	r.request.Cache = append(r.request.Cache, op)
+}
+
+// RecordHTTP 记录一次出站HTTP调用
+func (r *DebugRecorder) RecordHTTP(call DebugHTTPCall, begin time.Time) {
+	if r == nil {
+		return
+	}
+	call.URL = truncateDebugValue(call.URL)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.reserveLocked() {
+		return
+	}
+	call.OffsetMs = debugMs(begin.Sub(r.start))
+	r.request.HTTP = append(r.request.HTTP, call)
+}
+
+// RecordNotification 记录一条发送的通知
+func (r *DebugRecorder) RecordNotification(notification DebugNotification, begin time.Time) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.reserveLocked() {
+		return
+	}
+	notification.OffsetMs = debugMs(begin.Sub(r.start))
+	r.request.Notifications = append(r.request.Notifications, notification)
+}
+
+// reserveLocked 占用一条记录的名额，超过上限时只计数，调用方需持有锁
+func (r *DebugRecorder) reserveLocked() bool {
+	if r.maxEntries > 0 && r.entries >= r.maxEntries {
+		r.request.Dropped++
+		return false
+	}
+	r.entries++
+	return true
+}
+
+// Finish 结束记录，返回请求的调试记录
+// 请求结束后仍在执行的后台操作可能继续写入记录器，返回的记录是结束时的副本
+func (r *DebugRecorder) Finish(statusCode int, route, userID string) DebugRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	request := r.request
+	request.StatusCode = statusCode
+	request.Route = route
+	request.UserID = userID
+	request.DurationMs = debugMs(time.Since(r.start))
+	request.SQL = append([]DebugQuery{}, r.request.SQL...)
+	request.Cache = append([]DebugCacheOperation{}, r.request.Cache...)
+	request.HTTP = append([]DebugHTTPCall{}, r.request.HTTP...)
+	request.Notifications = append([]DebugNotification{}, r.request.Notifications...)
+
+	request.Queries = len(request.SQL)
+	for _, query := range request.SQL {
+		request.QueryMs += query.DurationMs
+		if query.Error != "" {
+			request.QueryErrors++
+		}
+	}
+	request.CacheOps = len(request.Cache)
+	for _, op := range request.Cache {
+		if op.Hit == nil {
+			continue
+		}
+		if *op.Hit {
+			request.CacheHits++
+		} else {
+			request.CacheMisses++
+		}
+	}
+	request.HTTPCalls = len(request.HTTP)
+	for _, call := range request.HTTP {
+		request.HTTPMs += call.DurationMs
+	}
+	request.NotificationCount = len(request.Notifications)
+	return request
+}
+
+// debugMs 转换为毫秒
+func debugMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// truncateDebugValue 截断过长的记录值
+func truncateDebugValue(value string) string {
+	if len(value) > debugMaxStatementSize {
+		return value[:debugMaxStatementSize] + "... [TRUNCATED]"
+	}
+	return value
+}
+
+// DebugRequestStore 请求调试记录存储
+// 功能说明：
+// 1. 按请求ID保存调试记录，只保存在当前实例的内存中
+// 2. 超过保留时间的记录在保存和读取时清理，数量超过上限时丢弃最早的记录
+type DebugRequestStore struct {
+	mu          sync.Mutex
+	ttl         time.Duration
+	maxRequests int
+	requests    map[string]*debugStoreEntry
+	order       []string
+	now         func() time.Time
+}
+
+// debugStoreEntry 保存的调试记录
+type debugStoreEntry struct {
+	request DebugRequest
+	savedAt time.Time
+}
+
+// NewDebugRequestStore 创建请求调试记录存储，config 为 nil 时保留15分钟、最多200个请求
+func NewDebugRequestStore(config *Config.DebugModeConfig) *DebugRequestStore {
+	store := &DebugRequestStore{
+		ttl:         15 * time.Minute,
+		maxRequests: 200,
+		requests:    make(map[string]*debugStoreEntry),
+		now:         time.Now,
+	}
+	if config != nil {
+		if config.TTL > 0 {
+			store.ttl = config.TTL
+		}
+		if config.MaxRequests > 0 {
+			store.maxRequests = config.MaxRequests
+		}
+	}
+	return store
+}
+
+// SetClock 设置时钟，用于测试
+func (s *DebugRequestStore) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// Save 保存调试记录，同一请求ID的记录被覆盖
+func (s *DebugRequestStore) Save(request DebugRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneLocked()
+	if _, exists := s.requests[request.RequestID]; exists {
+		s.removeLocked(request.RequestID)
+	}
+	for len(s.order) >= s.maxRequests {
+		s.removeLocked(s.order[0])
+	}
+	s.requests[request.RequestID] = &debugStoreEntry{request: request, savedAt: s.now()}
+	s.order = append(s.order, request.RequestID)
+}
+
+// Get 按请求ID获取调试记录
+func (s *DebugRequestStore) Get(requestID string) (DebugRequest, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked()
+	entry, ok := s.requests[requestID]
+	if !ok {
+		return DebugRequest{}, false
+	}
+	return entry.request, true
+}
+
+// List 按时间从新到旧返回调试记录摘要
+func (s *DebugRequestStore) List() []DebugRequestSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked()
+	summaries := make([]DebugRequestSummary, 0, len(s.order))
+	for i := len(s.order) - 1; i >= 0; i-- {
+		summaries = append(summaries, s.requests[s.order[i]].request.DebugRequestSummary)
+	}
+	return summaries
+}
+
+// Clear 清空调试记录，返回清除的数量
+func (s *DebugRequestStore) Clear() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	removed := len(s.order)
+	s.requests = make(map[string]*debugStoreEntry)
+	s.order = nil
+	return removed
+}
+
+// pruneLocked 清理超过保留时间的记录，调用方需持有锁
+func (s *DebugRequestStore) pruneLocked() {
+	cutoff := s.now().Add(-s.ttl)
+	expired := 0
+	for _, id := range s.order {
+		if !s.requests[id].savedAt.Before(cutoff) {
+			break
+		}
+		delete(s.requests, id)
+		expired++
+	}
+	s.order = s.order[expired:]
+}
+
+// removeLocked 删除记录，调用方需持有锁
+func (s *DebugRequestStore) removeLocked(requestID string) {
+	delete(s.requests, requestID)
+	for i, id := range s.order {
+		if id == requestID {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			return
+		}
+	}
+}
+
+// DebugRedisHook Redis客户端钩子，把请求上下文中带有调试记录器的命令记录到记录器
+type DebugRedisHook struct{}
+
+// DialHook 不处理建立连接
+func (DebugRedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook 记录单条命令
+func (DebugRedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		recorder := DebugRecorderFromContext(ctx)
+		if recorder == nil {
+			return next(ctx, cmd)
+		}
+		begin := time.Now()
+		err := next(ctx, cmd)
+		recorder.RecordCache(debugRedisOperation(cmd, err, time.Since(begin), false), begin)
+		return err
+	}
+}
+
+// ProcessPipelineHook 记录管道中的每条命令
+func (DebugRedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		recorder := DebugRecorderFromContext(ctx)
+		if recorder == nil {
+			return next(ctx, cmds)
+		}
+		begin := time.Now()
+		err := next(ctx, cmds)
+		duration := time.Since(begin)
+		for _, cmd := range cmds {
+			cmdErr := cmd.Err()
+			if cmdErr == nil {
+				cmdErr = err
+			}
+			recorder.RecordCache(debugRedisOperation(cmd, cmdErr, duration, true), begin)
+		}
+		return err
+	}
+}
+
+// debugRedisOperation 转换Redis命令，读取命令按是否返回 redis.Nil 判断命中
+// 钩子返回后客户端才把错误写入命令，err 为钩子链返回的错误
+func debugRedisOperation(cmd redis.Cmder, err error, duration time.Duration, pipeline bool) DebugCacheOperation {
+	op := DebugCacheOperation{
+		Store:      DebugCacheStoreRedis,
+		Operation:  strings.ToLower(cmd.Name()),
+		Pipeline:   pipeline,
+		DurationMs: debugMs(duration),
+	}
+	if args := cmd.Args(); len(args) > 1 {
+		if key, ok := args[1].(string); ok {
+			op.Key = key
+		}
+	}
+	switch op.Operation {
+	case "get", "hget", "getex", "getdel", "hgetall", "mget", "hmget":
+		hit := err == nil
+		op.Hit = &hit
+	}
+	if err != nil && err != redis.Nil {
+		op.Error = err.Error()
+	}
+	return op
+}
+
+// rememberModelDebug 读取模型缓存并把命中结果记录到 ctx 中的调试记录器
+func rememberModelDebug[T any](ctx context.Context, cache *ModelCache, table, key string, load func() (T, error)) (T, error) {
+	recorder := DebugRecorderFromContext(ctx)
+	if recorder == nil || cache == nil {
+		return RememberModel(cache, table, key, load)
+	}
+	begin := time.Now()
+	loaded := false
+	value, err := RememberModel(cache, table, key, func() (T, error) {
+		loaded = true
+		return load()
+	})
+	hit := !loaded && err == nil
+	op := DebugCacheOperation{
+		Store:      DebugCacheStoreModelCache,
+		Operation:  "get",
+		Key:        table + ":" + key,
+		Hit:        &hit,
+		DurationMs: debugMs(time.Since(begin)),
+	}
+	if err != nil {
+		op.Error = err.Error()
+	}
+	recorder.RecordCache(op, begin)
+	return value, err
+}
@@ -0,0 +1,491 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 关联数据的删除策略
+const (
+	DeletePolicyCascade  = "cascade"  // 一并删除关联记录
+	DeletePolicyNullify  = "nullify"  // 关联字段置空
+	DeletePolicyRestrict = "restrict" // 存在关联记录时拒绝删除
+)
+
+var (
+	// ErrDeletionNotFound 记录不存在或已删除
+	ErrDeletionNotFound = errors.New("记录不存在或已删除")
+	// ErrDeletionRestricted 存在关联数据，不能删除
+	ErrDeletionRestricted = errors.New("存在关联数据，不能删除")
+	// ErrDeletionParentDeleted 所属记录在回收站中，需要先恢复所属记录
+	ErrDeletionParentDeleted = errors.New("所属记录在回收站中，需要先恢复所属记录")
+)
+
+// DeletionRelation 引用被删除记录的关联数据及其删除策略
+type DeletionRelation struct {
+	Name   string             // 关联数据名称，用于错误信息
+	Model  func() interface{} // 关联模型指针
+	Column string             // 引用被删除记录ID的字段
+	Policy string             // 删除策略
+}
+
+// deletionTarget 可删除的模型及引用它的关联数据
+type deletionTarget struct {
+	model     func() interface{}
+	relations []DeletionRelation
+}
+
+// OrphanReport 孤儿数据统计：关联字段引用的记录已不存在（包括回收站）
+type OrphanReport struct {
+	Type   string `json:"type"`   // 被引用的回收站类型
+	Table  string `json:"table"`  // 关联表
+	Column string `json:"column"` // 关联字段
+	Policy string `json:"policy"` // 删除策略
+	Count  int64  `json:"count"`  // 孤儿记录数
+}
+
+// DeletionService 删除编排服务
+// 功能说明：
+// 1. 为用户、告警规则、监控仪表板和API密钥定义关联数据的删除策略：cascade 一并删除、nullify 置空、restrict 拒绝删除
+// 2. Delete 在一个事务中检查 restrict 关联、将记录和支持软删除的 cascade 关联数据以同一删除时间放入回收站；删除用户后撤销其已签发的token
+// 3. Restore 恢复记录及与其一同删除的关联数据，所属记录在回收站中时拒绝单独恢复
+// 4. ForceDelete 彻底删除时执行全部 cascade 和 nullify 策略，关联记录超过 batch 条时先分批处理，每批一个事务，最后在一个事务中删除记录
+// 5. CheckIntegrity 统计关联字段引用的记录已不存在的孤儿数据，IntegrityCollector 作为监控指标定期输出
+//
+// 不支持软删除的 cascade 关联数据（如仪表板组件、站内通知）和 nullify 关联字段在彻底删除时才处理，记录在回收站中时保持不变，恢复后数据完整。
+type DeletionService struct {
+	db      *gorm.DB
+	batch   int
+	targets map[string]deletionTarget
+	now     func() time.Time
+
+	mu     sync.Mutex
+	tokens *TokenBlacklistService
+}
+
+// NewDeletionService 创建删除编排服务，config 为 nil 时关联记录每批处理500条
+func NewDeletionService(db *gorm.DB, config *Config.TrashConfig) *DeletionService {
+	service := &DeletionService{
+		db:    db,
+		batch: 500,
+		now:   time.Now,
+		targets: map[string]deletionTarget{
+			TrashUsers: {
+				model: func() interface{} { return &Models.User{} },
+				relations: []DeletionRelation{
+					{Name: "文章", Model: func() interface{} { return &Models.Post{} }, Column: "user_id", Policy: DeletePolicyRestrict},
+					{Name: "API密钥", Model: func() interface{} { return &Models.ApiKey{} }, Column: "user_id", Policy: DeletePolicyCascade},
+					{Name: "API密钥使用记录", Model: func() interface{} { return &Models.ApiKeyUsage{} }, Column: "user_id", Policy: DeletePolicyCascade},
+					{Name: "访问控制", Model: func() interface{} { return &Models.AccessControl{} }, Column: "user_id", Policy: DeletePolicyCascade},
+					{Name: "密码历史", Model: func() interface{} { return &Models.PasswordHistory{} }, Column: "user_id", Policy: DeletePolicyCascade},
+					{Name: "账户锁定", Model: func() interface{} { return &Models.AccountLockout{} }, Column: "user_id", Policy: DeletePolicyCascade},
+					{Name: "站内通知", Model: func() interface{} { return &Models.Notification{} }, Column: "user_id", Policy: DeletePolicyCascade},
+					{Name: "偏好设置", Model: func() interface{} { return &Models.UserSetting{} }, Column: "user_id", Policy: DeletePolicyCascade},
+					{Name: "团队成员", Model: func() interface{} { return &Models.TeamMember{} }, Column: "user_id", Policy: DeletePolicyCascade},
+					{Name: "行为画像", Model: func() interface{} { return &Models.UserBehaviorProfile{} }, Column: "user_id", Policy: DeletePolicyCascade},
+					{Name: "安全事件", Model: func() interface{} { return &Models.SecurityEvent{} }, Column: "user_id", Policy: DeletePolicyNullify},
+					{Name: "安全告警", Model: func() interface{} { return &Models.SecurityAlert{} }, Column: "user_id", Policy: DeletePolicyNullify},
+					{Name: "安全告警确认人", Model: func() interface{} { return &Models.SecurityAlert{} }, Column: "acknowledged_by", Policy: DeletePolicyNullify},
+					{Name: "安全告警解决人", Model: func() interface{} { return &Models.SecurityAlert{} }, Column: "resolved_by", Policy: DeletePolicyNullify},
+					{Name: "监控告警确认人", Model: func() interface{} { return &Models.Alert{} }, Column: "acknowledged_by", Policy: DeletePolicyNullify},
+					{Name: "监控告警解决人", Model: func() interface{} { return &Models.Alert{} }, Column: "resolved_by", Policy: DeletePolicyNullify},
+					{Name: "安全响应记录", Model: func() interface{} { return &Models.SecurityResponseExecution{} }, Column: "user_id", Policy: DeletePolicyNullify},
+				},
+			},
+			TrashAlertRules: {
+				model: func() interface{} { return &Models.AlertRule{} },
+			},
+			TrashDashboards: {
+				model: func() interface{} { return &Models.MonitoringDashboard{} },
+				relations: []DeletionRelation{
+					{Name: "仪表板组件", Model: func() interface{} { return &Models.MonitoringWidget{} }, Column: "dashboard_id", Policy: DeletePolicyCascade},
+				},
+			},
+			TrashApiKeys: {
+				model: func() interface{} { return &Models.ApiKey{} },
+				relations: []DeletionRelation{
+					{Name: "API密钥使用记录", Model: func() interface{} { return &Models.ApiKeyUsage{} }, Column: "api_key_id", Policy: DeletePolicyCascade},
+				},
+			},
+		},
+	}
+	if config != nil && config.PurgeBatch > 0 {
+		service.batch = config.PurgeBatch
+	}
+	return service
+}
+
+// SetClock 设置删除时间使用的时钟
+func (s *DeletionService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// SetTokenBlacklistService 设置token黑名单服务，删除用户后撤销其已签发的token
+func (s *DeletionService) SetTokenBlacklistService(tokens *TokenBlacklistService) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = tokens
+}
+
+// Relations 类型的关联数据及删除策略
+func (s *DeletionService) Relations(name string) ([]DeletionRelation, error) {
+	target, err := s.target(name)
+	if err != nil {
+		return nil, err
+	}
+	return append([]DeletionRelation(nil), target.relations...), nil
+}
+
+// target 查找可删除的类型
+func (s *DeletionService) target(name string) (deletionTarget, error) {
+	target, ok := s.targets[name]
+	if !ok {
+		return target, fmt.Errorf("%w：%s", ErrTrashTypeUnknown, name)
+	}
+	return target, nil
+}
+
+// existingRelations 关联表已创建的关联数据，未启用的模块没有对应的表
+func (s *DeletionService) existingRelations(tx *gorm.DB, target deletionTarget) []DeletionRelation {
+	relations := make([]DeletionRelation, 0, len(target.relations))
+	for _, relation := range target.relations {
+		if tx.Migrator().HasTable(relation.Model()) {
+			relations = append(relations, relation)
+		}
+	}
+	return relations
+}
+
+// Delete 删除记录，记录及支持软删除的 cascade 关联数据进入回收站
+func (s *DeletionService) Delete(name string, id uint) error {
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		return s.DeleteTx(tx, name, id)
+	}); err != nil {
+		return err
+	}
+	if name == TrashUsers {
+		s.revokeTokens(id)
+	}
+	return nil
+}
+
+// DeleteTx 在调用方的事务中删除记录，不撤销token，供批量任务使用
+func (s *DeletionService) DeleteTx(tx *gorm.DB, name string, id uint) error {
+	target, err := s.target(name)
+	if err != nil {
+		return err
+	}
+	relations := s.existingRelations(tx, target)
+	if err := checkRestrict(tx, relations, []uint{id}); err != nil {
+		return err
+	}
+
+	// 删除时间精确到秒，恢复时按删除时间找到一同删除的关联数据，不受数据库时间精度影响
+	deletedAt := s.now().Truncate(time.Second)
+	result := tx.Model(target.model()).Where("id = ?", id).UpdateColumn("deleted_at", deletedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDeletionNotFound
+	}
+	for _, relation := range relations {
+		if relation.Policy != DeletePolicyCascade || !softDeletable(tx, relation.Model()) {
+			continue
+		}
+		if err := tx.Model(relation.Model()).Where(relation.Column+" = ?", id).UpdateColumn("deleted_at", deletedAt).Error; err != nil {
+			return fmt.Errorf("删除%s失败: %v", relation.Name, err)
+		}
+	}
+	return nil
+}
+
+// Restore 恢复回收站中的记录及与其一同删除的关联数据
+func (s *DeletionService) Restore(name string, id uint) error {
+	target, err := s.target(name)
+	if err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var record struct{ DeletedAt *time.Time }
+		result := tx.Unscoped().Model(target.model()).Select("deleted_at").
+			Where("id = ? AND deleted_at IS NOT NULL", id).Scan(&record)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 || record.DeletedAt == nil {
+			return ErrTrashItemNotFound
+		}
+		if err := s.checkParents(tx, name, target, id); err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Model(target.model()).Where("id = ?", id).UpdateColumn("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		for _, relation := range s.existingRelations(tx, target) {
+			if relation.Policy != DeletePolicyCascade || !softDeletable(tx, relation.Model()) {
+				continue
+			}
+			if err := tx.Unscoped().Model(relation.Model()).Where(relation.Column+" = ? AND deleted_at = ?", id, *record.DeletedAt).
+				UpdateColumn("deleted_at", nil).Error; err != nil {
+				return fmt.Errorf("恢复%s失败: %v", relation.Name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// checkParents 记录作为其他类型的 cascade 关联数据时，所属记录在回收站中不能单独恢复
+func (s *DeletionService) checkParents(tx *gorm.DB, name string, target deletionTarget, id uint) error {
+	table := tableOf(tx, target.model())
+	for parentName, parent := range s.targets {
+		if parentName == name {
+			continue
+		}
+		for _, relation := range parent.relations {
+			if relation.Policy != DeletePolicyCascade || tableOf(tx, relation.Model()) != table {
+				continue
+			}
+			var count int64
+			err := tx.Unscoped().Model(parent.model()).
+				Where("deleted_at IS NOT NULL AND id IN (?)", tx.Unscoped().Model(target.model()).Select(relation.Column).Where("id = ?", id)).
+				Count(&count).Error
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				return fmt.Errorf("%w：%s", ErrDeletionParentDeleted, parentName)
+			}
+		}
+	}
+	return nil
+}
+
+// ForceDelete 彻底删除记录，执行关联数据的全部删除策略
+// 关联记录超过 batch 条时先分批处理，每批一个事务，避免长时间锁表；最后在一个事务中处理剩余的关联记录并删除记录
+// restrict 关联数据中未删除的记录阻止彻底删除，回收站中的记录一并彻底删除
+func (s *DeletionService) ForceDelete(name string, ids []uint) error {
+	target, err := s.target(name)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	relations := s.existingRelations(s.db, target)
+
+	if err := checkRestrict(s.db, relations, ids); err != nil {
+		return err
+	}
+
+	var related int64
+	for _, relation := range relations {
+		if relation.Policy == DeletePolicyRestrict {
+			continue
+		}
+		var count int64
+		if err := s.db.Unscoped().Model(relation.Model()).Where(relation.Column+" IN ?", ids).Count(&count).Error; err != nil {
+			return err
+		}
+		related += count
+	}
+	if related > int64(s.batch) {
+		for _, relation := range relations {
+			if relation.Policy == DeletePolicyRestrict {
+				continue
+			}
+			if err := s.applyInBatches(relation, ids); err != nil {
+				return err
+			}
+		}
+	}
+
+	// restrict 关联数据在事务中再检查一次，剩余的只有回收站中的记录（如与用户一同删除的文章），一并彻底删除
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkRestrict(tx, relations, ids); err != nil {
+			return err
+		}
+		for _, relation := range relations {
+			if relation.Policy == DeletePolicyRestrict {
+				relation.Policy = DeletePolicyCascade
+			}
+			if err := applyRelation(tx, relation, ids, nil); err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Where("id IN ?", ids).Delete(target.model()).Error
+	})
+}
+
+// checkRestrict 存在未删除的 restrict 关联数据时返回 ErrDeletionRestricted
+func checkRestrict(tx *gorm.DB, relations []DeletionRelation, ids []uint) error {
+	for _, relation := range relations {
+		if relation.Policy != DeletePolicyRestrict {
+			continue
+		}
+		var count int64
+		if err := tx.Model(relation.Model()).Where(relation.Column+" IN ?", ids).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("%w：存在 %d 条%s", ErrDeletionRestricted, count, relation.Name)
+		}
+	}
+	return nil
+}
+
+// applyInBatches 每批 batch 条执行关联数据的删除策略，直到没有引用这些记录的关联数据
+func (s *DeletionService) applyInBatches(relation DeletionRelation, ids []uint) error {
+	for {
+		var childIDs []uint
+		if err := s.db.Unscoped().Model(relation.Model()).Where(relation.Column+" IN ?", ids).
+			Order("id").Limit(s.batch).Pluck("id", &childIDs).Error; err != nil {
+			return err
+		}
+		if len(childIDs) == 0 {
+			return nil
+		}
+		if err := s.db.Transaction(func(tx *gorm.DB) error {
+			return applyRelation(tx, relation, ids, childIDs)
+		}); err != nil {
+			return err
+		}
+		if len(childIDs) < s.batch {
+			return nil
+		}
+	}
+}
+
+// applyRelation 执行 cascade 或 nullify 策略，childIDs 不为空时只处理这些关联记录
+func applyRelation(tx *gorm.DB, relation DeletionRelation, ids, childIDs []uint) error {
+	query := tx.Unscoped().Model(relation.Model()).Where(relation.Column+" IN ?", ids)
+	if len(childIDs) > 0 {
+		query = query.Where("id IN ?", childIDs)
+	}
+	var err error
+	switch relation.Policy {
+	case DeletePolicyCascade:
+		err = query.Delete(relation.Model()).Error
+	case DeletePolicyNullify:
+		err = query.UpdateColumn(relation.Column, nil).Error
+	}
+	if err != nil {
+		return fmt.Errorf("处理%s失败: %v", relation.Name, err)
+	}
+	return nil
+}
+
+// revokeTokens 撤销用户已签发的token
+func (s *DeletionService) revokeTokens(userID uint) {
+	s.mu.Lock()
+	tokens := s.tokens
+	s.mu.Unlock()
+	if tokens == nil {
+		return
+	}
+	if err := tokens.RevokeUserTokens(userID, tokenLifetime()); err != nil {
+		log.Printf("撤销用户token失败: user_id=%d, error=%v", userID, err)
+	}
+}
+
+// CheckIntegrity 统计每个关联字段的孤儿数据，关联字段为空或0的记录不计入
+func (s *DeletionService) CheckIntegrity(ctx context.Context) ([]OrphanReport, error) {
+	names := make([]string, 0, len(s.targets))
+	for name := range s.targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	db := s.db.WithContext(ctx)
+	var reports []OrphanReport
+	for _, name := range names {
+		target := s.targets[name]
+		if !db.Migrator().HasTable(target.model()) {
+			continue
+		}
+		parentTable := tableOf(db, target.model())
+		for _, relation := range s.existingRelations(db, target) {
+			table := tableOf(db, relation.Model())
+			column := table + "." + relation.Column
+			var count int64
+			err := db.Unscoped().Model(relation.Model()).
+				Where(column + " IS NOT NULL AND " + column + " <> 0").
+				Where("NOT EXISTS (SELECT 1 FROM " + parentTable + " WHERE " + parentTable + ".id = " + column + ")").
+				Count(&count).Error
+			if err != nil {
+				return reports, fmt.Errorf("统计%s的孤儿数据失败: %v", relation.Name, err)
+			}
+			reports = append(reports, OrphanReport{Type: name, Table: table, Column: relation.Column, Policy: relation.Policy, Count: count})
+		}
+	}
+	return reports, nil
+}
+
+// IntegrityCollector 孤儿数据监控指标采集器
+// 输出 data_integrity_orphans_total，以及每个关联字段的 data_integrity_orphans_<表名>_<字段名>
+func (s *DeletionService) IntegrityCollector() MetricCollector {
+	return NewMetricCollector("data_integrity", func(ctx context.Context) (map[string]float64, error) {
+		reports, err := s.CheckIntegrity(ctx)
+		if err != nil {
+			return nil, err
+		}
+		values := map[string]float64{"data_integrity_orphans_total": 0}
+		for _, report := range reports {
+			values["data_integrity_orphans_"+report.Table+"_"+report.Column] = float64(report.Count)
+			values["data_integrity_orphans_total"] += float64(report.Count)
+		}
+		return values, nil
+	})
+}
+
+// softDeletable 模型是否支持软删除
+func softDeletable(db *gorm.DB, model interface{}) bool {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return false
+	}
+	field := stmt.Schema.LookUpField("DeletedAt")
+	return field != nil && field.FieldType == reflect.TypeOf(gorm.DeletedAt{})
+}
+
+// tableOf 模型的表名
+func tableOf(db *gorm.DB, model interface{}) string {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return ""
+	}
+	return stmt.Schema.Table
+}
+
+var (
+	defaultDeletionService   *DeletionService
+	defaultDeletionServiceMu sync.RWMutex
+)
+
+// SetDefaultDeletionService 设置全局删除编排服务
+func SetDefaultDeletionService(service *DeletionService) {
+	defaultDeletionServiceMu.Lock()
+	defer defaultDeletionServiceMu.Unlock()
+	defaultDeletionService = service
+}
+
+// DefaultDeletionService 获取全局删除编排服务，未设置时使用 db 创建不撤销token的服务
+func DefaultDeletionService(db *gorm.DB) *DeletionService {
+	defaultDeletionServiceMu.RLock()
+	defer defaultDeletionServiceMu.RUnlock()
+	if defaultDeletionService != nil {
+		return defaultDeletionService
+	}
+	return NewDeletionService(db, nil)
+}
@@ -0,0 +1,224 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/SBOM"
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DependencyVulnerabilityEventType 新发现依赖漏洞的安全事件类型
+const DependencyVulnerabilityEventType = "dependency_vulnerability"
+
+// DependencyVulnerabilityService 依赖漏洞跟踪服务
+// 功能说明：
+// 1. 用内嵌依赖清单中的模块和版本查询OSV漏洞库
+// 2. 每个漏洞和模块保存一条跟踪记录，第一次发现（或已解决后再次出现）时记录安全事件，事件级别为漏洞严重程度
+// 3. 不再被报告的漏洞（如已升级依赖或漏洞库撤回）标记为已解决
+// 4. 按 DependencyCheckInterval 定期检查，漏洞库新公开的漏洞在下一次检查时发现
+type DependencyVulnerabilityService struct {
+	db        *gorm.DB
+	config    *Config.SecurityScanConfig
+	scanner   *OSVScanner
+	locations map[string]OSVModule // 漏洞记录的 Location -> 模块
+	mutex     sync.Mutex
+	now       func() time.Time
+}
+
+// DependencyCheckResult 依赖漏洞检查结果
+type DependencyCheckResult struct {
+	CheckedModules int                              `json:"checked_modules"`
+	Open           int                              `json:"open"` // 检查后未解决的漏洞数
+	New            []Models.DependencyVulnerability `json:"new"`
+	Resolved       int                              `json:"resolved"`
+	CheckedAt      time.Time                        `json:"checked_at"`
+}
+
+// NewDependencyVulnerabilityService 创建依赖漏洞跟踪服务，config 为空时使用默认漏洞扫描配置
+func NewDependencyVulnerabilityService(db *gorm.DB, config *Config.SecurityScanConfig, document *SBOM.Document) *DependencyVulnerabilityService {
+	if config == nil {
+		defaults := &Config.SecurityConfig{}
+		defaults.SetDefaults()
+		config = &defaults.Scan
+	}
+	s := &DependencyVulnerabilityService{db: db, config: config, now: time.Now}
+	scanner := NewOSVScanner(config.OSVURL)
+	var modules []OSVModule
+	if document != nil {
+		for _, component := range document.Components {
+			if component.Version == "" || component.Version == "(devel)" {
+				continue
+			}
+			modules = append(modules, OSVModule{Path: component.Path, Version: component.Version})
+		}
+	}
+	s.SetScanner(scanner, modules)
+	return s
+}
+
+// SetScanner 设置查询漏洞库的插件和检查的模块（用于测试或自定义OSV地址）
+func (s *DependencyVulnerabilityService) SetScanner(scanner *OSVScanner, modules []OSVModule) {
+	scanner.SetModules(modules)
+	s.scanner = scanner
+	s.locations = make(map[string]OSVModule, len(modules))
+	for _, module := range modules {
+		s.locations[module.Path+"@"+module.Version] = module
+	}
+}
+
+// SetClock 设置时钟（用于测试）
+func (s *DependencyVulnerabilityService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// Check 检查依赖漏洞并更新跟踪记录
+func (s *DependencyVulnerabilityService) Check(ctx context.Context) (*DependencyCheckResult, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	vulns, err := s.scanner.Scan(ctx)
+	if err != nil {
+		if vulns == nil {
+			return nil, err
+		}
+		// 部分漏洞详情获取失败时仍然记录漏洞编号
+		log.Printf("依赖漏洞检查部分失败: %v", err)
+	}
+
+	now := s.now()
+	result := &DependencyCheckResult{CheckedModules: len(s.locations), New: []Models.DependencyVulnerability{}, CheckedAt: now}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var records []Models.DependencyVulnerability
+		if err := tx.Find(&records).Error; err != nil {
+			return err
+		}
+		existing := make(map[string]*Models.DependencyVulnerability, len(records))
+		for i := range records {
+			existing[records[i].VulnID+"\x00"+records[i].Module] = &records[i]
+		}
+
+		seen := make(map[string]bool)
+		var events []Models.SecurityEvent
+		for _, vuln := range vulns {
+			module, ok := s.locations[vuln.Location]
+			if !ok {
+				continue
+			}
+			key := vuln.ID + "\x00" + module.Path
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			record := existing[key]
+			isNew := record == nil || record.ResolvedAt != nil
+			if record == nil {
+				record = &Models.DependencyVulnerability{VulnID: vuln.ID, Module: module.Path, FirstSeenAt: now}
+			}
+			record.Version = module.Version
+			record.Severity = vuln.Severity
+			record.Summary = vuln.Description
+			record.Recommendation = vuln.Recommendation
+			record.References = vuln.References
+			record.LastSeenAt = now
+			record.ResolvedAt = nil
+			if err := tx.Save(record).Error; err != nil {
+				return err
+			}
+			if isNew {
+				result.New = append(result.New, *record)
+				events = append(events, dependencyVulnerabilityEvent(record))
+			}
+		}
+
+		for key, record := range existing {
+			if seen[key] || record.ResolvedAt != nil {
+				continue
+			}
+			if err := tx.Model(record).Update("resolved_at", now).Error; err != nil {
+				return err
+			}
+			result.Resolved++
+		}
+		result.Open = len(seen)
+
+		if len(events) > 0 {
+			return tx.Create(&events).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// dependencyVulnerabilityEvent 新发现依赖漏洞的安全事件
+func dependencyVulnerabilityEvent(record *Models.DependencyVulnerability) Models.SecurityEvent {
+	details, _ := json.Marshal(map[string]interface{}{
+		"vuln_id":        record.VulnID,
+		"module":         record.Module,
+		"version":        record.Version,
+		"severity":       record.Severity,
+		"summary":        record.Summary,
+		"recommendation": record.Recommendation,
+		"references":     record.References,
+	})
+	return Models.SecurityEvent{
+		EventType:  DependencyVulnerabilityEventType,
+		EventLevel: record.Severity,
+		Resource:   truncateUTF8(record.Module+"@"+record.Version, 255),
+		Action:     truncateUTF8(record.VulnID, 100),
+		Details:    string(details),
+	}
+}
+
+// List 获取依赖漏洞跟踪记录，includeResolved 为 false 时只返回未解决的漏洞
+func (s *DependencyVulnerabilityService) List(includeResolved bool) ([]Models.DependencyVulnerability, error) {
+	query := s.db.Model(&Models.DependencyVulnerability{})
+	if !includeResolved {
+		query = query.Where("resolved_at IS NULL")
+	}
+	var records []Models.DependencyVulnerability
+	if err := query.Order("first_seen_at DESC").Order("id DESC").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Start 按 DependencyCheckInterval 定期检查依赖漏洞，启动时立即检查一次
+func (s *DependencyVulnerabilityService) Start(ctx context.Context) {
+	if s.config.DependencyCheckInterval <= 0 {
+		return
+	}
+	go func() {
+		check := func() {
+			result, err := s.Check(ctx)
+			if err != nil {
+				log.Printf("依赖漏洞检查失败: %v", err)
+				return
+			}
+			if len(result.New) > 0 {
+				log.Printf("发现 %d 个新的依赖漏洞", len(result.New))
+			}
+		}
+		check()
+
+		ticker := time.NewTicker(s.config.DependencyCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				check()
+			}
+		}
+	}()
+}
@@ -1,9 +1,8 @@
 package Services
 
 import (
-	"crypto/tls"
+	"context"
 	"fmt"
-	"net/smtp"
 	"time"
 )
 
@@ -67,32 +66,26 @@ func (s *EmailService) SendEmailVerificationEmail(to, verificationToken, usernam
 }
 
 // sendEmail 发送邮件
+//
+// 已设置全局邮件服务时放入发送队列，由队列负责重试和投递记录；否则直接通过SMTP发送。
 func (s *EmailService) sendEmail(to, subject, body, contentType string) error {
-	headers := make(map[string]string)
-	headers["From"] = s.config.From
-	headers["To"] = to
-	headers["Subject"] = subject
-	headers["MIME-Version"] = "1.0"
-	headers["Content-Type"] = fmt.Sprintf("%s; charset=UTF-8", contentType)
-
-	message := ""
-	for k, v := range headers {
-		message += fmt.Sprintf("%s: %s\r\n", k, v)
+	message := &Mail{From: s.config.From, To: []string{to}, Subject: subject}
+	if contentType == "text/html" {
+		message.HTML = body
+	} else {
+		message.Text = body
 	}
-	message += "\r\n" + body
 
-	addr := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
-	auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+	if mailService := DefaultMailService(); mailService != nil {
+		_, err := mailService.Queue(message)
+		return err
+	}
 
+	driver := newSMTPMailDriver(s.config.Host, s.config.Port, s.config.Username, s.config.Password, s.config.UseTLS, 0)
 	var err error
 	for i := 0; i < 3; i++ {
-		if s.config.UseTLS {
-			err = s.sendEmailWithTLS(to, addr, auth, message)
-		} else {
-			err = smtp.SendMail(addr, auth, s.config.From, []string{to}, []byte(message))
-		}
-
-		if err == nil {
+		_, err = driver.Send(context.Background(), message)
+		if err == nil || IsPermanentMailError(err) {
 			break
 		}
 
@@ -104,40 +97,6 @@ func (s *EmailService) sendEmail(to, subject, body, contentType string) error {
 	return err
 }
 
-// sendEmailWithTLS 使用TLS发送邮件
-func (s *EmailService) sendEmailWithTLS(to, addr string, auth smtp.Auth, message string) error {
-	conn, err := smtp.Dial(addr)
-	if err != nil {
-		return err
-	}
-	defer conn.Close()
-
-	if err = conn.StartTLS(&tls.Config{ServerName: s.config.Host}); err != nil {
-		return err
-	}
-
-	if err = conn.Auth(auth); err != nil {
-		return err
-	}
-
-	if err = conn.Mail(s.config.From); err != nil {
-		return err
-	}
-
-	if err = conn.Rcpt(to); err != nil {
-		return err
-	}
-
-	w, err := conn.Data()
-	if err != nil {
-		return err
-	}
-	defer w.Close()
-
-	_, err = w.Write([]byte(message))
-	return err
-}
-
 // getBaseURL 获取基础URL
 func (s *EmailService) getBaseURL() string {
 	return "http://localhost:8080"
@@ -0,0 +1,560 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Utils"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	mathrand "math/rand"
+	"net/http"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrorScopeKey 请求错误上下文在 gin.Context 和请求上下文中的键
+const ErrorScopeKey = "error_scope"
+
+// errorReporterModule 本项目的模块路径，用于标记事件堆栈中属于项目代码的帧
+const errorReporterModule = "cloud-platform-api/"
+
+// emailPattern 不发送个人信息时脱敏邮箱地址
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+// ErrorReportTransport 错误事件发送接口，每个上报服务一个实现
+type ErrorReportTransport interface {
+	Name() string
+	Send(ctx context.Context, event *ErrorEvent) error
+}
+
+// ErrorEvent 上报的错误事件
+type ErrorEvent struct {
+	ID             string                 `json:"id"`
+	Timestamp      time.Time              `json:"timestamp"`
+	Level          string                 `json:"level"` // error、fatal
+	Message        string                 `json:"message"`
+	ExceptionType  string                 `json:"exception_type"`
+	ExceptionValue string                 `json:"exception_value"`
+	Handled        bool                   `json:"handled"` // panic 为 false
+	Frames         []ErrorStackFrame      `json:"frames"`  // 从最内层调用开始
+	Release        string                 `json:"release,omitempty"`
+	Environment    string                 `json:"environment,omitempty"`
+	ServerName     string                 `json:"server_name,omitempty"`
+	Tags           map[string]string      `json:"tags,omitempty"`
+	Extra          map[string]interface{} `json:"extra,omitempty"`
+	Request        *ErrorRequestInfo      `json:"request,omitempty"`
+	UserID         string                 `json:"user_id,omitempty"`
+	Breadcrumbs    []Breadcrumb           `json:"breadcrumbs,omitempty"`
+}
+
+// ErrorStackFrame 堆栈帧
+type ErrorStackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	InApp    bool   `json:"in_app"`
+}
+
+// ErrorRequestInfo 发生错误的请求信息
+type ErrorRequestInfo struct {
+	Method      string            `json:"method"`
+	URL         string            `json:"url"`
+	QueryString string            `json:"query_string,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	ClientIP    string            `json:"client_ip,omitempty"`
+}
+
+// Breadcrumb 面包屑，记录错误发生前请求内的业务、审计和安全操作
+type Breadcrumb struct {
+	Timestamp time.Time              `json:"timestamp"`
+	Category  string                 `json:"category"`
+	Message   string                 `json:"message"`
+	Level     string                 `json:"level"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// ErrorScope 单个请求的错误上下文
+//
+// 恢复中间件为每个请求创建，同时保存到 gin.Context 和请求上下文中，
+// 服务层无论拿到哪种上下文都能添加面包屑。所有方法对 nil 接收者安全。
+type ErrorScope struct {
+	mu          sync.Mutex
+	max         int
+	breadcrumbs []Breadcrumb
+	request     *ErrorRequestInfo
+	userID      string
+}
+
+// NewErrorScope 创建请求错误上下文，maxBreadcrumbs 为保留的面包屑条数
+func NewErrorScope(maxBreadcrumbs int) *ErrorScope {
+	return &ErrorScope{max: maxBreadcrumbs}
+}
+
+// WithErrorScope 把错误上下文保存到 ctx 中
+func WithErrorScope(ctx context.Context, scope *ErrorScope) context.Context {
+	return context.WithValue(ctx, ErrorScopeKey, scope)
+}
+
+// ErrorScopeFromContext 获取 ctx 中的错误上下文，不存在时返回 nil
+//
+// 使用字符串键，gin.Context 从 c.Keys 读取，请求上下文从 context 值读取。
+func ErrorScopeFromContext(ctx context.Context) *ErrorScope {
+	if ctx == nil {
+		return nil
+	}
+	scope, _ := ctx.Value(ErrorScopeKey).(*ErrorScope)
+	return scope
+}
+
+// AddBreadcrumb 添加面包屑，超出上限时丢弃最早的一条
+func (s *ErrorScope) AddBreadcrumb(breadcrumb Breadcrumb) {
+	if s == nil || s.max <= 0 {
+		return
+	}
+	if breadcrumb.Timestamp.IsZero() {
+		breadcrumb.Timestamp = time.Now()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.breadcrumbs) >= s.max {
+		s.breadcrumbs = append(s.breadcrumbs[:0], s.breadcrumbs[len(s.breadcrumbs)-s.max+1:]...)
+	}
+	s.breadcrumbs = append(s.breadcrumbs, breadcrumb)
+}
+
+// SetRequest 记录请求信息
+func (s *ErrorScope) SetRequest(req *http.Request, clientIP string) {
+	if s == nil || req == nil {
+		return
+	}
+	headers := make(map[string]string, len(req.Header))
+	for name := range req.Header {
+		headers[name] = req.Header.Get(name)
+	}
+	info := &ErrorRequestInfo{
+		Method:      req.Method,
+		URL:         req.URL.Path,
+		QueryString: req.URL.RawQuery,
+		Headers:     headers,
+		ClientIP:    clientIP,
+	}
+	s.mu.Lock()
+	s.request = info
+	s.mu.Unlock()
+}
+
+// SetUser 记录当前请求的用户ID，认证中间件在认证成功后调用
+func (s *ErrorScope) SetUser(userID string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.userID = userID
+	s.mu.Unlock()
+}
+
+// snapshot 返回面包屑、请求信息和用户ID的副本
+func (s *ErrorScope) snapshot() ([]Breadcrumb, *ErrorRequestInfo, string) {
+	if s == nil {
+		return nil, nil, ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	breadcrumbs := append([]Breadcrumb(nil), s.breadcrumbs...)
+	var request *ErrorRequestInfo
+	if s.request != nil {
+		copied := *s.request
+		request = &copied
+	}
+	return breadcrumbs, request, s.userID
+}
+
+// ErrorReporterStats 错误上报统计
+type ErrorReporterStats struct {
+	Provider    string `json:"provider"`
+	Sent        int64  `json:"sent"`
+	Failed      int64  `json:"failed"`
+	Sampled     int64  `json:"sampled"`      // 被采样过滤的事件数
+	RateLimited int64  `json:"rate_limited"` // 超过每分钟上限的事件数
+	Dropped     int64  `json:"dropped"`      // 队列已满被丢弃的事件数
+}
+
+// ErrorReporter 错误上报服务
+// 功能说明：
+// 1. panic 和 LogError 记录的错误转换为事件，附带版本、环境、请求、用户ID和面包屑
+// 2. 按采样比例和每分钟上限过滤事件，防止错误风暴耗尽上报服务配额
+// 3. 事件在发送前脱敏：复用日志脱敏规则，不发送个人信息时去掉客户端IP、Cookie、认证头和邮箱地址
+// 4. 事件在后台队列中异步发送，队列满时丢弃并计数，不阻塞请求
+type ErrorReporter struct {
+	config      *Config.ErrorReportingConfig
+	environment string
+	serverName  string
+	transport   ErrorReportTransport
+	masker      *LogMasker
+	queue       chan *ErrorEvent
+	random      func() float64
+
+	limitMu     sync.Mutex
+	windowStart time.Time
+	windowCount int
+
+	sent        int64
+	failed      int64
+	sampled     int64
+	rateLimited int64
+	dropped     int64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewErrorReporter 创建错误上报服务
+//
+// masking 为日志脱敏配置，无论日志脱敏是否启用，上报的事件都会脱敏。
+// environment 为部署环境（server.environment），配置了 Environment 时使用配置的环境标签。
+func NewErrorReporter(config *Config.ErrorReportingConfig, masking Config.LogMaskingConfig, environment string) (*ErrorReporter, error) {
+	var transport ErrorReportTransport
+	switch config.Provider {
+	case "sentry":
+		sentry, err := NewSentryTransport(config.DSN, config.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		transport = sentry
+	case "bugsnag":
+		transport = NewBugsnagTransport(config.APIKey, config.Endpoint, config.Timeout)
+	default:
+		return nil, fmt.Errorf("不支持的错误上报服务: %s", config.Provider)
+	}
+
+	masker, err := NewLogMasker(masking)
+	if err != nil {
+		// 自定义规则有误时退回内置规则，与日志脱敏保持一致
+		masking.Patterns = nil
+		if masker, err = NewLogMasker(masking); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.Environment != "" {
+		environment = config.Environment
+	}
+	queueSize := config.QueueSize
+	if queueSize <= 0 {
+		queueSize = 100
+	}
+	hostname, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ErrorReporter{
+		config:      config,
+		environment: environment,
+		serverName:  hostname,
+		transport:   transport,
+		masker:      masker,
+		queue:       make(chan *ErrorEvent, queueSize),
+		random:      mathrand.Float64,
+		ctx:         ctx,
+		cancel:      cancel,
+	}, nil
+}
+
+// NewScope 创建请求错误上下文，保留配置的面包屑条数
+func (r *ErrorReporter) NewScope() *ErrorScope {
+	return NewErrorScope(r.config.MaxBreadcrumbs)
+}
+
+// SetTransport 替换事件发送方式（必须在 Start 之前调用，用于测试）
+func (r *ErrorReporter) SetTransport(transport ErrorReportTransport) {
+	r.transport = transport
+}
+
+// SetRandom 替换采样使用的随机数（用于测试）
+func (r *ErrorReporter) SetRandom(random func() float64) {
+	r.random = random
+}
+
+// Start 启动后台发送
+func (r *ErrorReporter) Start() {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		for {
+			select {
+			case <-r.ctx.Done():
+				// 关闭前发送队列中剩余的事件
+				for {
+					select {
+					case event := <-r.queue:
+						r.send(event)
+					default:
+						return
+					}
+				}
+			case event := <-r.queue:
+				r.send(event)
+			}
+		}
+	}()
+}
+
+// Close 发送完队列中的事件后停止，最多等待 timeout
+func (r *ErrorReporter) Close(timeout time.Duration) {
+	r.cancel()
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
+
+// send 发送单个事件
+func (r *ErrorReporter) send(event *ErrorEvent) {
+	timeout := r.config.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	// 关闭时仍要发送剩余事件，不使用已取消的 r.ctx
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := r.transport.Send(ctx, event); err != nil {
+		atomic.AddInt64(&r.failed, 1)
+		fmt.Printf("[WARN] 错误上报失败(%s): %v\n", r.transport.Name(), err)
+		return
+	}
+	atomic.AddInt64(&r.sent, 1)
+}
+
+// Stats 获取上报统计
+func (r *ErrorReporter) Stats() ErrorReporterStats {
+	return ErrorReporterStats{
+		Provider:    r.transport.Name(),
+		Sent:        atomic.LoadInt64(&r.sent),
+		Failed:      atomic.LoadInt64(&r.failed),
+		Sampled:     atomic.LoadInt64(&r.sampled),
+		RateLimited: atomic.LoadInt64(&r.rateLimited),
+		Dropped:     atomic.LoadInt64(&r.dropped),
+	}
+}
+
+// CaptureError 上报错误，返回事件是否进入发送队列
+//
+// ctx 中的 request_id、user_id 和错误上下文（请求信息、面包屑）会附加到事件中，fields 作为附加数据。
+func (r *ErrorReporter) CaptureError(ctx context.Context, err error, message string, fields map[string]interface{}) bool {
+	if r == nil || err == nil {
+		return false
+	}
+	event := &ErrorEvent{
+		Level:          "error",
+		Message:        message,
+		ExceptionType:  fmt.Sprintf("%T", err),
+		ExceptionValue: err.Error(),
+		Handled:        true,
+		Frames:         callerFrames(3, false),
+	}
+	return r.capture(ctx, event, r.config.SampleRate, fields)
+}
+
+// CapturePanic 上报 panic，必须在恢复 panic 的 defer 函数中调用，堆栈才包含发生 panic 的位置
+func (r *ErrorReporter) CapturePanic(ctx context.Context, recovered interface{}, fields map[string]interface{}) bool {
+	if r == nil {
+		return false
+	}
+	event := &ErrorEvent{
+		Level:          "fatal",
+		Message:        fmt.Sprintf("panic: %v", recovered),
+		ExceptionType:  "panic",
+		ExceptionValue: fmt.Sprint(recovered),
+		Handled:        false,
+		Frames:         callerFrames(3, true),
+	}
+	if err, ok := recovered.(error); ok {
+		event.ExceptionType = fmt.Sprintf("%T", err)
+	}
+	return r.capture(ctx, event, r.config.PanicSampleRate, fields)
+}
+
+// capture 采样、限流、补充上下文并脱敏后放入发送队列
+func (r *ErrorReporter) capture(ctx context.Context, event *ErrorEvent, sampleRate float64, fields map[string]interface{}) bool {
+	if sampleRate < 1 && r.random() >= sampleRate {
+		atomic.AddInt64(&r.sampled, 1)
+		return false
+	}
+	if !r.allow(time.Now()) {
+		atomic.AddInt64(&r.rateLimited, 1)
+		return false
+	}
+
+	event.ID = newErrorEventID()
+	event.Timestamp = time.Now().UTC()
+	event.Release = r.config.Release
+	event.Environment = r.environment
+	event.ServerName = r.serverName
+	event.Tags = map[string]string{}
+	if len(fields) > 0 {
+		event.Extra = make(map[string]interface{}, len(fields))
+		for key, value := range fields {
+			event.Extra[key] = value
+		}
+	}
+
+	if ctx != nil {
+		breadcrumbs, request, userID := ErrorScopeFromContext(ctx).snapshot()
+		event.Breadcrumbs = breadcrumbs
+		event.Request = request
+		event.UserID = userID
+		if value := ctx.Value("user_id"); value != nil {
+			event.UserID = fmt.Sprint(value)
+		}
+		if requestID := Utils.RequestIDFromContext(ctx); requestID != "" {
+			event.Tags["request_id"] = requestID
+		}
+		if correlationID := Utils.CorrelationIDFromContext(ctx); correlationID != "" {
+			event.Tags["correlation_id"] = correlationID
+		}
+	}
+	if event.Request != nil {
+		event.Tags["http.method"] = event.Request.Method
+		event.Tags["http.route"] = event.Request.URL
+	}
+
+	r.scrub(event)
+
+	select {
+	case r.queue <- event:
+		return true
+	default:
+		atomic.AddInt64(&r.dropped, 1)
+		return false
+	}
+}
+
+// allow 检查每分钟上报上限
+func (r *ErrorReporter) allow(now time.Time) bool {
+	if r.config.MaxEventsPerMinute <= 0 {
+		return true
+	}
+	r.limitMu.Lock()
+	defer r.limitMu.Unlock()
+	if now.Sub(r.windowStart) >= time.Minute {
+		r.windowStart = now
+		r.windowCount = 0
+	}
+	if r.windowCount >= r.config.MaxEventsPerMinute {
+		return false
+	}
+	r.windowCount++
+	return true
+}
+
+// scrub 脱敏事件内容
+func (r *ErrorReporter) scrub(event *ErrorEvent) {
+	scrubString := func(value string) string {
+		value = r.masker.MaskString(value)
+		if !r.config.SendDefaultPII {
+			value = emailPattern.ReplaceAllString(value, r.masker.replacement)
+		}
+		return value
+	}
+	var scrubValue func(value interface{}) interface{}
+	scrubValue = func(value interface{}) interface{} {
+		switch v := value.(type) {
+		case string:
+			return scrubString(v)
+		case map[string]interface{}:
+			for key, item := range v {
+				v[key] = scrubValue(item)
+			}
+			return v
+		case []interface{}:
+			for i, item := range v {
+				v[i] = scrubValue(item)
+			}
+			return v
+		default:
+			return v
+		}
+	}
+	scrubFields := func(fields map[string]interface{}) map[string]interface{} {
+		if fields == nil {
+			return nil
+		}
+		return scrubValue(r.masker.MaskFields(fields)).(map[string]interface{})
+	}
+
+	event.Message = scrubString(event.Message)
+	event.ExceptionValue = scrubString(event.ExceptionValue)
+	event.Extra = scrubFields(event.Extra)
+	for i := range event.Breadcrumbs {
+		event.Breadcrumbs[i].Message = scrubString(event.Breadcrumbs[i].Message)
+		event.Breadcrumbs[i].Data = scrubFields(event.Breadcrumbs[i].Data)
+	}
+
+	if request := event.Request; request != nil {
+		request.URL = scrubString(request.URL)
+		request.QueryString = scrubString(request.QueryString)
+		headers := make(map[string]string, len(request.Headers))
+		for name, value := range request.Headers {
+			lower := strings.ToLower(name)
+			if !r.config.SendDefaultPII && (lower == "cookie" || lower == "authorization" || lower == "x-forwarded-for" || lower == "x-real-ip") {
+				continue
+			}
+			if r.masker.IsSensitiveField(name) {
+				headers[name] = r.masker.replacement
+				continue
+			}
+			headers[name] = scrubString(value)
+		}
+		request.Headers = headers
+		if !r.config.SendDefaultPII {
+			request.ClientIP = ""
+		}
+	}
+}
+
+// callerFrames 获取调用堆栈，skip 为跳过的帧数，跳过 runtime 包内部的帧
+//
+// fromPanic 为 true 时丢弃 runtime.gopanic 之前的帧（恢复中间件自身），堆栈从发生 panic 的位置开始。
+func callerFrames(skip int, fromPanic bool) []ErrorStackFrame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var result []ErrorStackFrame
+	for {
+		frame, more := frames.Next()
+		if fromPanic && frame.Function == "runtime.gopanic" {
+			result = nil
+		}
+		if !strings.HasPrefix(frame.Function, "runtime.") {
+			result = append(result, ErrorStackFrame{
+				Function: frame.Function,
+				File:     frame.File,
+				Line:     frame.Line,
+				InApp:    strings.HasPrefix(frame.Function, errorReporterModule) || strings.HasPrefix(frame.Function, "main."),
+			})
+		}
+		if !more {
+			break
+		}
+	}
+	return result
+}
+
+// newErrorEventID 生成32位十六进制事件ID
+func newErrorEventID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%032x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,337 @@
+package Services
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 上报服务在出站HTTP客户端中的依赖名称
+const (
+	sentryDependency  = "error_reporter_sentry"
+	bugsnagDependency = "error_reporter_bugsnag"
+)
+
+// errorReporterClientName 上报请求中的客户端名称
+const errorReporterClientName = "cloud-platform-api"
+
+// errorReporterClientVersion 上报请求中的客户端版本
+const errorReporterClientVersion = "1.0.0"
+
+// SentryTransport Sentry 事件发送
+// 功能说明：
+// 1. 从 DSN 解析 envelope 接口地址和公钥，使用 X-Sentry-Auth 认证
+// 2. 事件转换为 Sentry 事件格式：异常和堆栈（最外层调用在前）、标签、附加数据、用户、请求和面包屑
+// 3. 支持自建 Sentry（DSN 中带路径前缀）
+type SentryTransport struct {
+	endpoint  string
+	publicKey string
+	client    *OutboundHTTPClient
+}
+
+// NewSentryTransport 创建 Sentry 事件发送，DSN 无效时返回错误
+func NewSentryTransport(dsn string, timeout time.Duration) (*SentryTransport, error) {
+	endpoint, publicKey, err := Config.ParseSentryDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &SentryTransport{
+		endpoint:  endpoint,
+		publicKey: publicKey,
+		client:    outboundClientFor(sentryDependency, timeout),
+	}, nil
+}
+
+// Name 上报服务名称
+func (t *SentryTransport) Name() string {
+	return "sentry"
+}
+
+// Send 发送事件
+func (t *SentryTransport) Send(ctx context.Context, event *ErrorEvent) error {
+	payload, err := json.Marshal(sentryEvent(event))
+	if err != nil {
+		return err
+	}
+	header, _ := json.Marshal(map[string]string{
+		"event_id": event.ID,
+		"sent_at":  time.Now().UTC().Format(time.RFC3339),
+	})
+	itemHeader, _ := json.Marshal(map[string]interface{}{"type": "event", "length": len(payload)})
+
+	var body bytes.Buffer
+	body.Write(header)
+	body.WriteByte('\n')
+	body.Write(itemHeader)
+	body.WriteByte('\n')
+	body.Write(payload)
+	body.WriteByte('\n')
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s/%s, sentry_key=%s",
+		errorReporterClientName, errorReporterClientVersion, t.publicKey))
+	return doErrorReportRequest(t.client, sentryDependency, req, "Sentry")
+}
+
+// sentryEvent 转换为 Sentry 事件格式
+func sentryEvent(event *ErrorEvent) map[string]interface{} {
+	// Sentry 的堆栈帧从最外层调用开始，发生错误的帧在最后
+	frames := make([]map[string]interface{}, 0, len(event.Frames))
+	for i := len(event.Frames) - 1; i >= 0; i-- {
+		frame := event.Frames[i]
+		module, function := splitFunctionName(frame.Function)
+		frames = append(frames, map[string]interface{}{
+			"function": function,
+			"module":   module,
+			"abs_path": frame.File,
+			"filename": frame.File,
+			"lineno":   frame.Line,
+			"in_app":   frame.InApp,
+		})
+	}
+
+	mechanism := "generic"
+	if !event.Handled {
+		mechanism = "panic"
+	}
+	payload := map[string]interface{}{
+		"event_id":    event.ID,
+		"timestamp":   event.Timestamp.Format(time.RFC3339Nano),
+		"platform":    "go",
+		"level":       event.Level,
+		"logger":      "error",
+		"server_name": event.ServerName,
+		"release":     event.Release,
+		"environment": event.Environment,
+		"message":     map[string]string{"formatted": event.Message},
+		"exception": map[string]interface{}{
+			"values": []map[string]interface{}{{
+				"type":       event.ExceptionType,
+				"value":      event.ExceptionValue,
+				"stacktrace": map[string]interface{}{"frames": frames},
+				"mechanism":  map[string]interface{}{"type": mechanism, "handled": event.Handled},
+			}},
+		},
+		"tags":  event.Tags,
+		"extra": event.Extra,
+		"sdk":   map[string]string{"name": errorReporterClientName, "version": errorReporterClientVersion},
+	}
+
+	if event.UserID != "" || (event.Request != nil && event.Request.ClientIP != "") {
+		user := map[string]string{}
+		if event.UserID != "" {
+			user["id"] = event.UserID
+		}
+		if event.Request != nil && event.Request.ClientIP != "" {
+			user["ip_address"] = event.Request.ClientIP
+		}
+		payload["user"] = user
+	}
+	if request := event.Request; request != nil {
+		payload["request"] = map[string]interface{}{
+			"method":       request.Method,
+			"url":          request.URL,
+			"query_string": request.QueryString,
+			"headers":      request.Headers,
+		}
+	}
+	if len(event.Breadcrumbs) > 0 {
+		breadcrumbs := make([]map[string]interface{}, 0, len(event.Breadcrumbs))
+		for _, breadcrumb := range event.Breadcrumbs {
+			breadcrumbs = append(breadcrumbs, map[string]interface{}{
+				"timestamp": breadcrumb.Timestamp.UTC().Format(time.RFC3339Nano),
+				"category":  breadcrumb.Category,
+				"message":   breadcrumb.Message,
+				"level":     breadcrumb.Level,
+				"data":      breadcrumb.Data,
+			})
+		}
+		payload["breadcrumbs"] = map[string]interface{}{"values": breadcrumbs}
+	}
+	return payload
+}
+
+// BugsnagTransport Bugsnag 事件发送
+// 功能说明：
+// 1. 使用 Error Reporting API（payload version 5）发送，Bugsnag-Api-Key 认证
+// 2. 版本对应 app.version，环境对应 app.releaseStage，标签和附加数据放在 metaData 中
+// 3. panic 标记为 unhandled，用于 Bugsnag 的稳定性统计
+type BugsnagTransport struct {
+	apiKey   string
+	endpoint string
+	client   *OutboundHTTPClient
+}
+
+// NewBugsnagTransport 创建 Bugsnag 事件发送
+func NewBugsnagTransport(apiKey, endpoint string, timeout time.Duration) *BugsnagTransport {
+	if endpoint == "" {
+		endpoint = "https://notify.bugsnag.com"
+	}
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &BugsnagTransport{
+		apiKey:   apiKey,
+		endpoint: endpoint,
+		client:   outboundClientFor(bugsnagDependency, timeout),
+	}
+}
+
+// Name 上报服务名称
+func (t *BugsnagTransport) Name() string {
+	return "bugsnag"
+}
+
+// Send 发送事件
+func (t *BugsnagTransport) Send(ctx context.Context, event *ErrorEvent) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"apiKey":         t.apiKey,
+		"payloadVersion": "5",
+		"notifier": map[string]string{
+			"name":    errorReporterClientName,
+			"version": errorReporterClientVersion,
+		},
+		"events": []map[string]interface{}{bugsnagEvent(event)},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Bugsnag-Api-Key", t.apiKey)
+	req.Header.Set("Bugsnag-Payload-Version", "5")
+	req.Header.Set("Bugsnag-Sent-At", time.Now().UTC().Format(time.RFC3339))
+	return doErrorReportRequest(t.client, bugsnagDependency, req, "Bugsnag")
+}
+
+// bugsnagEvent 转换为 Bugsnag 事件格式
+func bugsnagEvent(event *ErrorEvent) map[string]interface{} {
+	// Bugsnag 的堆栈帧从发生错误的位置开始
+	stacktrace := make([]map[string]interface{}, 0, len(event.Frames))
+	for _, frame := range event.Frames {
+		stacktrace = append(stacktrace, map[string]interface{}{
+			"file":       frame.File,
+			"lineNumber": frame.Line,
+			"method":     frame.Function,
+			"inProject":  frame.InApp,
+		})
+	}
+
+	severity, reason := "warning", "handledError"
+	if event.Level == "error" {
+		severity = "error"
+	}
+	if !event.Handled {
+		severity, reason = "error", "unhandledPanic"
+	}
+
+	metaData := map[string]interface{}{"message": map[string]string{"text": event.Message}}
+	if len(event.Tags) > 0 {
+		metaData["tags"] = event.Tags
+	}
+	if len(event.Extra) > 0 {
+		metaData["extra"] = event.Extra
+	}
+
+	payload := map[string]interface{}{
+		"exceptions": []map[string]interface{}{{
+			"errorClass": event.ExceptionType,
+			"message":    event.ExceptionValue,
+			"stacktrace": stacktrace,
+			"type":       "go",
+		}},
+		"severity":       severity,
+		"severityReason": map[string]string{"type": reason},
+		"unhandled":      !event.Handled,
+		"app": map[string]string{
+			"version":      event.Release,
+			"releaseStage": event.Environment,
+		},
+		"device":   map[string]string{"hostname": event.ServerName, "time": event.Timestamp.Format(time.RFC3339)},
+		"metaData": metaData,
+	}
+	if event.UserID != "" {
+		payload["user"] = map[string]string{"id": event.UserID}
+	}
+	if request := event.Request; request != nil {
+		payload["context"] = request.Method + " " + request.URL
+		info := map[string]interface{}{
+			"httpMethod": request.Method,
+			"url":        request.URL,
+			"headers":    request.Headers,
+		}
+		if request.QueryString != "" {
+			info["url"] = request.URL + "?" + request.QueryString
+		}
+		if request.ClientIP != "" {
+			info["clientIp"] = request.ClientIP
+		}
+		payload["request"] = info
+	}
+	if len(event.Breadcrumbs) > 0 {
+		breadcrumbs := make([]map[string]interface{}, 0, len(event.Breadcrumbs))
+		for _, breadcrumb := range event.Breadcrumbs {
+			breadcrumbs = append(breadcrumbs, map[string]interface{}{
+				"timestamp": breadcrumb.Timestamp.UTC().Format(time.RFC3339Nano),
+				"name":      truncateUTF8(breadcrumb.Message, 30),
+				"type":      "log",
+				"metaData":  mergeBreadcrumbData(breadcrumb),
+			})
+		}
+		payload["breadcrumbs"] = breadcrumbs
+	}
+	return payload
+}
+
+// mergeBreadcrumbData Bugsnag 面包屑没有分类和级别字段，放在 metaData 中
+func mergeBreadcrumbData(breadcrumb Breadcrumb) map[string]interface{} {
+	data := make(map[string]interface{}, len(breadcrumb.Data)+3)
+	for key, value := range breadcrumb.Data {
+		data[key] = value
+	}
+	data["category"] = breadcrumb.Category
+	data["level"] = breadcrumb.Level
+	data["message"] = breadcrumb.Message
+	return data
+}
+
+// doErrorReportRequest 发送上报请求，非2xx状态码返回错误
+func doErrorReportRequest(client *OutboundHTTPClient, dependency string, req *http.Request, provider string) error {
+	resp, err := client.Do(dependency, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s返回状态码 %d: %s", provider, resp.StatusCode, truncateString(string(data), 200))
+	}
+	return nil
+}
+
+// splitFunctionName 拆分完整函数名为包路径和函数名，如 cloud-platform-api/app/Services.(*UserService).Get
+func splitFunctionName(name string) (string, string) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return "", name
+	}
+	return name[:slash+1+dot], name[slash+1+dot+1:]
+}
@@ -0,0 +1,312 @@
+package Services
+
+import (
+	"bufio"
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 事件输出在出站HTTP客户端中的依赖名称，失败重试由发件箱负责
+const (
+	kafkaEventBrokerDependency    = "event_bus_kafka"
+	rabbitMQEventBrokerDependency = "event_bus_rabbitmq"
+)
+
+// KafkaEventBroker 通过 Kafka REST Proxy 投递事件
+// 功能说明：
+// 1. 使用 REST Proxy v2 接口 POST /topics/{topic}，消息值为事件JSON，消息键为聚合ID（没有时为事件ID），同一聚合的事件进入同一分区
+// 2. 响应中任一 offset 带 error_code 时视为失败
+type KafkaEventBroker struct {
+	config *Config.EventBusKafkaConfig
+	client *OutboundHTTPClient
+}
+
+// NewKafkaEventBroker 创建 Kafka 输出
+func NewKafkaEventBroker(config *Config.EventBusKafkaConfig) *KafkaEventBroker {
+	return &KafkaEventBroker{config: config, client: outboundClientFor(kafkaEventBrokerDependency, 10*time.Second)}
+}
+
+// Name 输出名称
+func (b *KafkaEventBroker) Name() string {
+	return "kafka"
+}
+
+// Publish 发送事件
+func (b *KafkaEventBroker) Publish(ctx context.Context, event DomainEvent) error {
+	key := event.AggregateID
+	if key == "" {
+		key = event.ID
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"records": []map[string]interface{}{{"key": key, "value": event}},
+	})
+	if err != nil {
+		return err
+	}
+
+	endpoint := strings.TrimRight(b.config.RestURL, "/") + "/topics/" + url.PathEscape(b.config.Topic)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+	if b.config.Username != "" {
+		req.SetBasicAuth(b.config.Username, b.config.Password)
+	}
+
+	resp, err := b.client.Do(kafkaEventBrokerDependency, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("Kafka REST Proxy返回状态码 %d: %s", resp.StatusCode, truncateString(string(data), 200))
+	}
+
+	var result struct {
+		Offsets []struct {
+			ErrorCode *int   `json:"error_code"`
+			Error     string `json:"error"`
+		} `json:"offsets"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("无法解析Kafka REST Proxy响应: %v", err)
+	}
+	for _, offset := range result.Offsets {
+		if offset.ErrorCode != nil {
+			return fmt.Errorf("Kafka写入失败: code=%d, %s", *offset.ErrorCode, offset.Error)
+		}
+	}
+	return nil
+}
+
+// Close 关闭输出
+func (b *KafkaEventBroker) Close() error {
+	return nil
+}
+
+// NATSEventBroker 通过 NATS 客户端协议投递事件
+// 功能说明：
+// 1. 连接后读取服务器 INFO，发送 CONNECT（带令牌或用户名密码）
+// 2. 每个事件发送 PUB 后跟一个 PING，收到 PONG 说明服务器已处理，收到 -ERR 视为失败
+// 3. 主题为前缀加事件类型；连接按需建立，出错时断开，下次投递重新连接
+type NATSEventBroker struct {
+	config *Config.EventBusNATSConfig
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewNATSEventBroker 创建 NATS 输出
+func NewNATSEventBroker(config *Config.EventBusNATSConfig) *NATSEventBroker {
+	return &NATSEventBroker{config: config}
+}
+
+// Name 输出名称
+func (b *NATSEventBroker) Name() string {
+	return "nats"
+}
+
+// Publish 发送事件
+func (b *NATSEventBroker) Publish(ctx context.Context, event DomainEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.conn == nil {
+		if err := b.connect(ctx); err != nil {
+			return err
+		}
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	b.conn.SetDeadline(deadline)
+
+	subject := b.config.SubjectPrefix + event.Type
+	message := fmt.Sprintf("PUB %s %d\r\n%s\r\nPING\r\n", subject, len(payload), payload)
+	if _, err := io.WriteString(b.conn, message); err != nil {
+		b.closeLocked()
+		return err
+	}
+	if err := b.awaitPong(); err != nil {
+		b.closeLocked()
+		return err
+	}
+	return nil
+}
+
+// connect 建立连接并完成握手
+func (b *NATSEventBroker) connect(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: 10 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", b.config.Address)
+	if err != nil {
+		return fmt.Errorf("连接NATS失败: %v", err)
+	}
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	reader := bufio.NewReader(conn)
+
+	line, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("NATS握手失败: %q", strings.TrimSpace(line))
+	}
+
+	options := map[string]interface{}{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     "cloud-platform-api",
+		"lang":     "go",
+		"version":  "1.0.0",
+	}
+	if b.config.Token != "" {
+		options["auth_token"] = b.config.Token
+	} else if b.config.Username != "" {
+		options["user"] = b.config.Username
+		options["pass"] = b.config.Password
+	}
+	connect, _ := json.Marshal(options)
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", connect); err != nil {
+		conn.Close()
+		return fmt.Errorf("NATS握手失败: %v", err)
+	}
+
+	b.conn, b.reader = conn, reader
+	if err := b.awaitPong(); err != nil {
+		b.closeLocked()
+		return fmt.Errorf("NATS认证失败: %v", err)
+	}
+	return nil
+}
+
+// awaitPong 等待 PONG，期间回应服务器的 PING
+func (b *NATSEventBroker) awaitPong() error {
+	for {
+		line, err := b.reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := io.WriteString(b.conn, "PONG\r\n"); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS返回错误: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+// closeLocked 断开连接，调用方持有锁
+func (b *NATSEventBroker) closeLocked() {
+	if b.conn != nil {
+		b.conn.Close()
+		b.conn, b.reader = nil, nil
+	}
+}
+
+// Close 关闭连接
+func (b *NATSEventBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closeLocked()
+	return nil
+}
+
+// RabbitMQEventBroker 通过 RabbitMQ 管理插件的HTTP接口投递事件
+// 功能说明：
+// 1. 使用 POST /api/exchanges/{vhost}/{exchange}/publish，路由键为事件类型，消息持久化
+// 2. message_id 为事件ID，type 为事件类型，消费方可据此去重和路由
+// 3. 消息没有路由到任何队列时视为失败，避免绑定缺失时事件被静默丢弃
+type RabbitMQEventBroker struct {
+	config *Config.EventBusRabbitMQConfig
+	client *OutboundHTTPClient
+}
+
+// NewRabbitMQEventBroker 创建 RabbitMQ 输出
+func NewRabbitMQEventBroker(config *Config.EventBusRabbitMQConfig) *RabbitMQEventBroker {
+	return &RabbitMQEventBroker{config: config, client: outboundClientFor(rabbitMQEventBrokerDependency, 10*time.Second)}
+}
+
+// Name 输出名称
+func (b *RabbitMQEventBroker) Name() string {
+	return "rabbitmq"
+}
+
+// Publish 发送事件
+func (b *RabbitMQEventBroker) Publish(ctx context.Context, event DomainEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"properties": map[string]interface{}{
+			"delivery_mode": 2,
+			"content_type":  "application/json",
+			"message_id":    event.ID,
+			"type":          event.Type,
+			"timestamp":     event.OccurredAt.Unix(),
+		},
+		"routing_key":      event.Type,
+		"payload":          string(payload),
+		"payload_encoding": "string",
+	})
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/api/exchanges/%s/%s/publish", strings.TrimRight(b.config.ManagementURL, "/"),
+		url.PathEscape(b.config.VHost), url.PathEscape(b.config.Exchange))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(b.config.Username, b.config.Password)
+
+	resp, err := b.client.Do(rabbitMQEventBrokerDependency, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("RabbitMQ返回状态码 %d: %s", resp.StatusCode, truncateString(string(data), 200))
+	}
+
+	var result struct {
+		Routed bool `json:"routed"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("无法解析RabbitMQ响应: %v", err)
+	}
+	if !result.Routed {
+		return fmt.Errorf("消息未路由到任何队列: exchange=%s, routing_key=%s", b.config.Exchange, event.Type)
+	}
+	return nil
+}
+
+// Close 关闭输出
+func (b *RabbitMQEventBroker) Close() error {
+	return nil
+}
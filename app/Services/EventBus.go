@@ -0,0 +1,510 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 领域事件类型
+const (
+	EventUserRegistered    = "user.registered" // 用户自行注册
+	EventUserCreated       = "user.created"    // 创建用户，包括注册、管理员创建和批量导入
+	EventAlertFired        = "alert.fired"
+	EventAlertResolved     = "alert.resolved"
+	EventBackupCompleted   = "backup.completed"
+	EventBackupFailed      = "backup.failed"
+	EventBackupDrillFailed = "backup.drill_failed" // 备份恢复演练失败
+	EventSecurityEventHigh = "security.event.high" // 记录了 high 或 critical 级别的安全事件
+)
+
+// EventAllTypes 订阅全部事件类型
+const EventAllTypes = "*"
+
+// outboxLease 领取事件的租期，分发器在租期内未完成投递时事件可被重新领取
+const outboxLease = 5 * time.Minute
+
+// maxOutboxBackoff 重试等待时间上限
+const maxOutboxBackoff = time.Hour
+
+// ErrOutboxEventNotFound 事件不存在
+var ErrOutboxEventNotFound = errors.New("事件不存在")
+
+// DomainEvent 投递给处理器和外部消息系统的领域事件
+type DomainEvent struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type,omitempty"`
+	AggregateID   string          `json:"aggregate_id,omitempty"`
+	Payload       json.RawMessage `json:"payload"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	RequestID     string          `json:"request_id,omitempty"`     // 产生事件的请求ID
+	CorrelationID string          `json:"correlation_id,omitempty"` // 产生事件的请求的关联ID
+}
+
+// DecodePayload 解析事件内容
+func (e DomainEvent) DecodePayload(v interface{}) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+// EventHandler 进程内事件处理器，返回错误时事件稍后重试
+// 同一事件可能投递多次，处理器应按事件ID去重或保证幂等
+type EventHandler func(ctx context.Context, event DomainEvent) error
+
+// EventBroker 外部消息系统
+type EventBroker interface {
+	Name() string
+	Publish(ctx context.Context, event DomainEvent) error
+	Close() error
+}
+
+// eventSubscription 进程内订阅
+type eventSubscription struct {
+	name      string
+	eventType string
+	handler   EventHandler
+}
+
+// EventBus 基于发件箱的领域事件总线
+// 功能说明：
+// 1. Publish 在调用方的事务中写入 outbox_events，业务数据和事件同时提交或回滚
+// 2. 分发器轮询到期的事件，先用条件更新领取（多实例部署时同一事件只被一个实例领取），再投递
+// 3. 每个进程内订阅和外部消息系统是一个投递目标，成功的目标记录在事件上，重试时只投递失败的目标
+// 4. 投递失败按指数退避重试，超过最大次数标记为失败；已投递的事件保留一段时间后删除
+// 投递语义为至少一次，消费方按事件ID去重
+type EventBus struct {
+	db     *gorm.DB
+	config Config.EventBusConfig
+
+	mu            sync.RWMutex
+	subscriptions []eventSubscription
+	brokers       []EventBroker
+
+	wake     chan struct{}
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	done     chan struct{}
+}
+
+// NewEventBus 创建事件总线
+func NewEventBus(db *gorm.DB, config Config.EventBusConfig) *EventBus {
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Second
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 10
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = 5 * time.Second
+	}
+	return &EventBus{
+		db:     db,
+		config: config,
+		wake:   make(chan struct{}, 1),
+		stopCh: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// NewEventBusFromConfig 创建事件总线并添加配置中启用的外部消息系统
+func NewEventBusFromConfig(db *gorm.DB, config Config.EventBusConfig) *EventBus {
+	bus := NewEventBus(db, config)
+	if config.Kafka.Enabled {
+		bus.AddBroker(NewKafkaEventBroker(&config.Kafka))
+	}
+	if config.NATS.Enabled {
+		bus.AddBroker(NewNATSEventBroker(&config.NATS))
+	}
+	if config.RabbitMQ.Enabled {
+		bus.AddBroker(NewRabbitMQEventBroker(&config.RabbitMQ))
+	}
+	return bus
+}
+
+// Subscribe 添加进程内订阅，eventType 为 EventAllTypes 时接收全部事件
+// name 用于记录投递状态，同一事件类型的订阅名称不能重复
+func (b *EventBus) Subscribe(eventType, name string, handler EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, subscription := range b.subscriptions {
+		if subscription.name == name && subscription.eventType == eventType {
+			b.subscriptions[i].handler = handler
+			return
+		}
+	}
+	b.subscriptions = append(b.subscriptions, eventSubscription{name: name, eventType: eventType, handler: handler})
+}
+
+// AddBroker 添加外部消息系统，同名时替换
+func (b *EventBus) AddBroker(broker EventBroker) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, existing := range b.brokers {
+		if existing.Name() == broker.Name() {
+			b.brokers[i] = broker
+			return
+		}
+	}
+	b.brokers = append(b.brokers, broker)
+}
+
+// Brokers 已添加的外部消息系统名称
+func (b *EventBus) Brokers() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	names := make([]string, 0, len(b.brokers))
+	for _, broker := range b.brokers {
+		names = append(names, broker.Name())
+	}
+	return names
+}
+
+// Publish 在事务中写入事件，tx 为 nil 时直接写入
+// payload 编码为JSON；事务提交后由分发器投递
+// tx 的上下文（tx.WithContext）中的请求ID和关联ID随事件保存，投递时传给处理器和外部消息系统
+func (b *EventBus) Publish(tx *gorm.DB, eventType, aggregateType, aggregateID string, payload interface{}) (*Models.OutboxEvent, error) {
+	if eventType == "" {
+		return nil, fmt.Errorf("事件类型不能为空")
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("编码事件内容失败: %v", err)
+	}
+	if tx == nil {
+		tx = b.db
+	}
+
+	now := time.Now()
+	event := &Models.OutboxEvent{
+		EventID:       uuid.NewString(),
+		EventType:     eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Payload:       string(data),
+		RequestID:     Utils.RequestIDFromContext(tx.Statement.Context),
+		CorrelationID: Utils.CorrelationIDFromContext(tx.Statement.Context),
+		Status:        Models.OutboxPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+	if err := tx.Create(event).Error; err != nil {
+		return nil, fmt.Errorf("写入事件失败: %v", err)
+	}
+
+	// 唤醒分发器；事务尚未提交时本轮读不到，下一轮再投递
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+	return event, nil
+}
+
+// Start 启动分发器
+func (b *EventBus) Start() {
+	go b.run()
+}
+
+// Stop 停止分发器，等待正在进行的投递完成并关闭外部连接
+func (b *EventBus) Stop() {
+	b.stopOnce.Do(func() {
+		close(b.stopCh)
+		<-b.done
+		b.mu.RLock()
+		defer b.mu.RUnlock()
+		for _, broker := range b.brokers {
+			if err := broker.Close(); err != nil {
+				log.Printf("关闭事件输出失败: broker=%s, error=%v", broker.Name(), err)
+			}
+		}
+	})
+}
+
+// run 分发循环
+func (b *EventBus) run() {
+	defer close(b.done)
+	ticker := time.NewTicker(b.config.PollInterval)
+	defer ticker.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-b.stopCh
+		cancel()
+	}()
+
+	for {
+		if _, err := b.DispatchPending(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("分发领域事件失败: %v", err)
+		}
+
+		select {
+		case <-b.stopCh:
+			return
+		case <-ticker.C:
+		case <-b.wake:
+		}
+	}
+}
+
+// DispatchPending 投递一批到期的事件，返回成功投递到全部目标的事件数
+func (b *EventBus) DispatchPending(ctx context.Context) (int, error) {
+	now := time.Now()
+	var candidates []Models.OutboxEvent
+	err := b.db.Where("status IN ? AND next_attempt_at <= ?", []string{Models.OutboxPending, Models.OutboxDispatching}, now).
+		Order("id").Limit(b.config.BatchSize).Find(&candidates).Error
+	if err != nil {
+		return 0, err
+	}
+
+	dispatched := 0
+	for i := range candidates {
+		if ctx.Err() != nil {
+			break
+		}
+		event := &candidates[i]
+		if !b.claim(event, now) {
+			continue
+		}
+		if b.deliver(ctx, event) {
+			dispatched++
+		}
+	}
+	return dispatched, nil
+}
+
+// claim 领取事件：投递次数作为版本号，只有未被其他实例领取时才能领取成功
+func (b *EventBus) claim(event *Models.OutboxEvent, now time.Time) bool {
+	leaseUntil := now.Add(outboxLease)
+	result := b.db.Model(&Models.OutboxEvent{}).
+		Where("id = ? AND status = ? AND attempts = ? AND next_attempt_at <= ?", event.ID, event.Status, event.Attempts, now).
+		Updates(map[string]interface{}{
+			"status":          Models.OutboxDispatching,
+			"next_attempt_at": leaseUntil,
+			"attempts":        gorm.Expr("attempts + 1"),
+		})
+	if result.Error != nil || result.RowsAffected != 1 {
+		return false
+	}
+	event.Status = Models.OutboxDispatching
+	event.NextAttemptAt = leaseUntil
+	event.Attempts++
+	return true
+}
+
+// deliver 投递到尚未成功的目标并保存结果，全部成功时返回 true
+func (b *EventBus) deliver(ctx context.Context, record *Models.OutboxEvent) bool {
+	event := DomainEvent{
+		ID:            record.EventID,
+		Type:          record.EventType,
+		AggregateType: record.AggregateType,
+		AggregateID:   record.AggregateID,
+		Payload:       json.RawMessage(record.Payload),
+		OccurredAt:    record.CreatedAt,
+		RequestID:     record.RequestID,
+		CorrelationID: record.CorrelationID,
+	}
+	if len(event.Payload) == 0 {
+		event.Payload = json.RawMessage("null")
+	}
+	// 处理器使用产生事件的请求的ID记录日志和发起出站请求
+	ctx = Utils.WithRequestIDs(ctx, record.RequestID, record.CorrelationID)
+
+	var failures []string
+	for _, target := range b.targets(record.EventType) {
+		if record.DeliveredTo(target.name) {
+			continue
+		}
+		if err := target.send(ctx, event); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", target.name, err))
+			continue
+		}
+		record.MarkDelivered(target.name)
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"delivered": record.Delivered}
+	switch {
+	case len(failures) == 0:
+		updates["status"] = Models.OutboxDispatched
+		updates["dispatched_at"] = now
+		updates["last_error"] = ""
+	case record.Attempts >= b.config.MaxAttempts:
+		updates["status"] = Models.OutboxFailed
+		updates["last_error"] = strings.Join(failures, "; ")
+		log.Printf("领域事件投递失败，已达最大次数: event=%s, type=%s, error=%s", record.EventID, record.EventType, updates["last_error"])
+	default:
+		updates["status"] = Models.OutboxPending
+		updates["next_attempt_at"] = now.Add(b.backoff(record.Attempts))
+		updates["last_error"] = strings.Join(failures, "; ")
+	}
+	if err := b.db.Model(&Models.OutboxEvent{}).Where("id = ?", record.ID).Updates(updates).Error; err != nil {
+		log.Printf("保存事件投递结果失败: event=%s, error=%v", record.EventID, err)
+	}
+	return len(failures) == 0
+}
+
+// eventTarget 投递目标
+type eventTarget struct {
+	name string
+	send func(ctx context.Context, event DomainEvent) error
+}
+
+// targets 事件类型对应的投递目标：匹配的订阅（名称前缀 handler:）和全部外部消息系统（名称前缀 broker:）
+func (b *EventBus) targets(eventType string) []eventTarget {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var targets []eventTarget
+	for _, subscription := range b.subscriptions {
+		if subscription.eventType != eventType && subscription.eventType != EventAllTypes {
+			continue
+		}
+		handler := subscription.handler
+		targets = append(targets, eventTarget{name: "handler:" + subscription.name, send: func(ctx context.Context, event DomainEvent) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("处理器异常: %v", r)
+				}
+			}()
+			return handler(ctx, event)
+		}})
+	}
+	for _, broker := range b.brokers {
+		targets = append(targets, eventTarget{name: "broker:" + broker.Name(), send: broker.Publish})
+	}
+	return targets
+}
+
+// backoff 第n次投递失败后的等待时间
+func (b *EventBus) backoff(attempts int) time.Duration {
+	delay := b.config.RetryBackoff
+	for i := 1; i < attempts && delay < maxOutboxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxOutboxBackoff)
+}
+
+// ListEvents 分页查询 outbox 事件
+func (b *EventBus) ListEvents(q *Utils.ListQuery, req Utils.PageRequest) ([]Models.OutboxEvent, Utils.PageMeta, error) {
+	query, order, err := q.Apply(b.db.Model(&Models.OutboxEvent{}), req)
+	if err != nil {
+		return nil, Utils.PageMeta{}, err
+	}
+	var events []Models.OutboxEvent
+	meta, err := Utils.Paginate(query, req, order, &events)
+	return events, meta, err
+}
+
+// Retry 重新投递失败的事件，已投递的目标不会重复投递
+func (b *EventBus) Retry(id uint) error {
+	result := b.db.Model(&Models.OutboxEvent{}).
+		Where("id = ? AND status = ?", id, Models.OutboxFailed).
+		Updates(map[string]interface{}{
+			"status":          Models.OutboxPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		b.db.Model(&Models.OutboxEvent{}).Where("id = ?", id).Count(&count)
+		if count == 0 {
+			return ErrOutboxEventNotFound
+		}
+		return fmt.Errorf("只能重试投递失败的事件")
+	}
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Purge 分批删除超过保留时间的已投递事件，由清理调度器定期运行
+func (b *EventBus) Purge(ctx context.Context) (int64, error) {
+	if b.config.Retention <= 0 {
+		return 0, nil
+	}
+	query := b.db.Where("status = ? AND dispatched_at < ?", Models.OutboxDispatched, time.Now().Add(-b.config.Retention))
+	return BatchDelete(ctx, query, &Models.OutboxEvent{})
+}
+
+// Stats 按状态统计事件数
+func (b *EventBus) Stats() (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	if err := b.db.Model(&Models.OutboxEvent{}).Select("status, COUNT(*) AS count").Group("status").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	stats := map[string]int64{
+		Models.OutboxPending:     0,
+		Models.OutboxDispatching: 0,
+		Models.OutboxDispatched:  0,
+		Models.OutboxFailed:      0,
+	}
+	for _, row := range rows {
+		stats[row.Status] = row.Count
+	}
+	return stats, nil
+}
+
+// Subscriptions 进程内订阅，按事件类型排序
+func (b *EventBus) Subscriptions() []map[string]string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	result := make([]map[string]string, 0, len(b.subscriptions))
+	for _, subscription := range b.subscriptions {
+		result = append(result, map[string]string{"event_type": subscription.eventType, "name": subscription.name})
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i]["event_type"] < result[j]["event_type"] })
+	return result
+}
+
+var (
+	defaultEventBus   *EventBus
+	defaultEventBusMu sync.RWMutex
+)
+
+// SetDefaultEventBus 设置全局事件总线，服务通过 PublishDomainEvent 发布事件
+func SetDefaultEventBus(bus *EventBus) {
+	defaultEventBusMu.Lock()
+	defer defaultEventBusMu.Unlock()
+	defaultEventBus = bus
+}
+
+// DefaultEventBus 获取全局事件总线，未启用时返回 nil
+func DefaultEventBus() *EventBus {
+	defaultEventBusMu.RLock()
+	defer defaultEventBusMu.RUnlock()
+	return defaultEventBus
+}
+
+// PublishDomainEvent 通过全局事件总线发布事件，事件总线未启用时忽略
+// tx 为业务事务，传 nil 时使用事件总线的数据库连接直接写入
+func PublishDomainEvent(tx *gorm.DB, eventType, aggregateType, aggregateID string, payload interface{}) error {
+	bus := DefaultEventBus()
+	if bus == nil {
+		return nil
+	}
+	_, err := bus.Publish(tx, eventType, aggregateType, aggregateID, payload)
+	return err
+}
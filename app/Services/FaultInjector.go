@@ -0,0 +1,446 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// 故障类型
+const (
+	FaultLatency      = "latency"       // 增加延迟
+	FaultError        = "error"         // 返回错误，路由返回指定状态码，依赖调用返回错误
+	FaultDBDisconnect = "db_disconnect" // 数据库查询返回连接断开（driver.ErrBadConn）
+	FaultRedisTimeout = "redis_timeout" // Redis命令返回超时
+)
+
+// 可注入故障的依赖
+const (
+	FaultServiceDatabase = "database"
+	FaultServiceRedis    = "redis"
+)
+
+// FaultInjectionAdminPath 故障注入管理接口路径，路由故障不作用于该路径，避免注入后无法撤销
+const FaultInjectionAdminPath = "/api/v1/admin/fault-injections"
+
+var (
+	// ErrFaultInjectionNotFound 故障注入不存在或已过期
+	ErrFaultInjectionNotFound = errors.New("故障注入不存在或已过期")
+	// ErrInvalidFaultInjection 故障注入参数无效
+	ErrInvalidFaultInjection = errors.New("故障注入参数无效")
+	// ErrFaultInjected 注入的故障，依赖调用返回的错误都包装了该错误
+	ErrFaultInjected = errors.New("注入的故障")
+)
+
+// FaultInjectionInput 创建故障注入的参数
+// 路由故障指定 path（可选 method），依赖故障指定 service，两者只能选一个；
+// db_disconnect 和 redis_timeout 分别固定作用于数据库和Redis
+type FaultInjectionInput struct {
+	Type            string  `json:"type" binding:"required"`
+	Method          string  `json:"method"`           // 请求方法，为空时匹配全部方法
+	Path            string  `json:"path"`             // 路由路径或注册的路由模板，以 * 结尾时按前缀匹配
+	Service         string  `json:"service"`          // database 或 redis
+	LatencyMs       int64   `json:"latency_ms"`       // 延迟毫秒数，其他类型在返回错误前等待该时间
+	StatusCode      int     `json:"status_code"`      // 路由错误的状态码，默认503
+	Message         string  `json:"message"`          // 错误消息
+	Probability     float64 `json:"probability"`      // 触发概率(0,1]，为0时总是触发
+	DurationSeconds int     `json:"duration_seconds"` // 有效期秒数，为0时使用默认有效期
+}
+
+// FaultInjection 生效中的故障注入
+type FaultInjection struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type"`
+	Method      string    `json:"method,omitempty"`
+	Path        string    `json:"path,omitempty"`
+	Service     string    `json:"service,omitempty"`
+	LatencyMs   int64     `json:"latency_ms,omitempty"`
+	StatusCode  int       `json:"status_code,omitempty"`
+	Message     string    `json:"message,omitempty"`
+	Probability float64   `json:"probability"`
+	Hits        int64     `json:"hits"` // 已触发次数
+	CreatedBy   uint      `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// Latency 注入的延迟
+func (f FaultInjection) Latency() time.Duration {
+	return time.Duration(f.LatencyMs) * time.Millisecond
+}
+
+// FaultInjector 故障注入器
+// 功能说明：
+// 1. 管理员按路由或依赖注入延迟、错误、数据库断连和Redis超时，注入保存在内存中，只作用于当前实例
+// 2. 路由故障由 FaultInjectionMiddleware 执行；数据库故障通过 GORM 回调、Redis故障通过客户端钩子执行
+// 3. 注入到期自动失效，查询和匹配时清理过期的注入
+type FaultInjector struct {
+	config Config.FaultInjectionConfig
+	mu     sync.Mutex
+	faults map[string]*FaultInjection
+	now    func() time.Time
+	random func() float64
+}
+
+// NewFaultInjector 创建故障注入器，config 为 nil 时使用默认有效期和延迟上限
+func NewFaultInjector(config *Config.FaultInjectionConfig) *FaultInjector {
+	cfg := Config.FaultInjectionConfig{
+		Enabled:         true,
+		DefaultDuration: 5 * time.Minute,
+		MaxDuration:     time.Hour,
+		MaxLatency:      30 * time.Second,
+	}
+	if config != nil {
+		if config.DefaultDuration > 0 {
+			cfg.DefaultDuration = config.DefaultDuration
+		}
+		if config.MaxDuration > 0 {
+			cfg.MaxDuration = config.MaxDuration
+		}
+		if config.MaxLatency > 0 {
+			cfg.MaxLatency = config.MaxLatency
+		}
+	}
+	return &FaultInjector{
+		config: cfg,
+		faults: make(map[string]*FaultInjection),
+		now:    time.Now,
+		random: rand.Float64,
+	}
+}
+
+// SetClock 设置判断过期使用的时钟
+func (f *FaultInjector) SetClock(now func() time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = now
+}
+
+// Inject 创建故障注入
+func (f *FaultInjector) Inject(input FaultInjectionInput, createdBy uint) (*FaultInjection, error) {
+	fault, err := f.normalize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := f.now()
+	fault.ID = uuid.NewString()
+	fault.CreatedBy = createdBy
+	fault.CreatedAt = now
+	fault.ExpiresAt = now.Add(time.Duration(input.DurationSeconds) * time.Second)
+	if input.DurationSeconds == 0 {
+		fault.ExpiresAt = now.Add(f.config.DefaultDuration)
+	}
+	f.faults[fault.ID] = fault
+	copied := *fault
+	return &copied, nil
+}
+
+// normalize 校验参数并补全默认值
+func (f *FaultInjector) normalize(input FaultInjectionInput) (*FaultInjection, error) {
+	fault := &FaultInjection{
+		Type:        input.Type,
+		Method:      strings.ToUpper(strings.TrimSpace(input.Method)),
+		Path:        strings.TrimSpace(input.Path),
+		Service:     strings.TrimSpace(input.Service),
+		LatencyMs:   input.LatencyMs,
+		StatusCode:  input.StatusCode,
+		Message:     strings.TrimSpace(input.Message),
+		Probability: input.Probability,
+	}
+
+	switch fault.Type {
+	case FaultLatency, FaultError:
+	case FaultDBDisconnect, FaultRedisTimeout:
+		service := FaultServiceDatabase
+		if fault.Type == FaultRedisTimeout {
+			service = FaultServiceRedis
+		}
+		if fault.Service != "" && fault.Service != service {
+			return nil, fmt.Errorf("%w：%s 只能作用于 %s", ErrInvalidFaultInjection, fault.Type, service)
+		}
+		fault.Service = service
+	default:
+		return nil, fmt.Errorf("%w：不支持的故障类型 %s，可选 latency、error、db_disconnect、redis_timeout", ErrInvalidFaultInjection, fault.Type)
+	}
+
+	if (fault.Path == "") == (fault.Service == "") {
+		return nil, fmt.Errorf("%w：path 和 service 必须且只能指定一个", ErrInvalidFaultInjection)
+	}
+	if fault.Path != "" && !strings.HasPrefix(fault.Path, "/") {
+		return nil, fmt.Errorf("%w：path 必须以 / 开头", ErrInvalidFaultInjection)
+	}
+	if fault.Service != "" {
+		if fault.Service != FaultServiceDatabase && fault.Service != FaultServiceRedis {
+			return nil, fmt.Errorf("%w：不支持的依赖 %s，可选 database、redis", ErrInvalidFaultInjection, fault.Service)
+		}
+		fault.Method = ""
+	}
+
+	if fault.LatencyMs < 0 || fault.Latency() > f.config.MaxLatency {
+		return nil, fmt.Errorf("%w：latency_ms 必须在 0 到 %d 之间", ErrInvalidFaultInjection, f.config.MaxLatency.Milliseconds())
+	}
+	if fault.Type == FaultLatency && fault.LatencyMs == 0 {
+		return nil, fmt.Errorf("%w：latency 类型必须指定 latency_ms", ErrInvalidFaultInjection)
+	}
+
+	if fault.Type == FaultError && fault.Path != "" {
+		if fault.StatusCode == 0 {
+			fault.StatusCode = http.StatusServiceUnavailable
+		}
+		if fault.StatusCode < 400 || fault.StatusCode > 599 {
+			return nil, fmt.Errorf("%w：status_code 必须在 400 到 599 之间", ErrInvalidFaultInjection)
+		}
+	} else {
+		fault.StatusCode = 0
+	}
+	if fault.Type != FaultLatency && fault.Message == "" {
+		fault.Message = "故障注入：服务暂时不可用"
+	}
+	if fault.Type == FaultLatency {
+		fault.Message = ""
+	}
+
+	if fault.Probability == 0 {
+		fault.Probability = 1
+	}
+	if fault.Probability < 0 || fault.Probability > 1 {
+		return nil, fmt.Errorf("%w：probability 必须在 0 到 1 之间", ErrInvalidFaultInjection)
+	}
+	if input.DurationSeconds < 0 || time.Duration(input.DurationSeconds)*time.Second > f.config.MaxDuration {
+		return nil, fmt.Errorf("%w：duration_seconds 不能超过 %d", ErrInvalidFaultInjection, int(f.config.MaxDuration.Seconds()))
+	}
+	return fault, nil
+}
+
+// List 获取生效中的故障注入，按创建时间排序
+func (f *FaultInjector) List() []FaultInjection {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pruneLocked()
+	faults := make([]FaultInjection, 0, len(f.faults))
+	for _, fault := range f.faults {
+		faults = append(faults, *fault)
+	}
+	sort.Slice(faults, func(i, j int) bool { return faults[i].CreatedAt.Before(faults[j].CreatedAt) })
+	return faults
+}
+
+// Remove 撤销故障注入
+func (f *FaultInjector) Remove(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pruneLocked()
+	if _, ok := f.faults[id]; !ok {
+		return ErrFaultInjectionNotFound
+	}
+	delete(f.faults, id)
+	return nil
+}
+
+// Clear 撤销全部故障注入，返回撤销的数量
+func (f *FaultInjector) Clear() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pruneLocked()
+	count := len(f.faults)
+	f.faults = make(map[string]*FaultInjection)
+	return count
+}
+
+// pruneLocked 清理过期的注入，调用方需持有锁
+func (f *FaultInjector) pruneLocked() {
+	now := f.now()
+	for id, fault := range f.faults {
+		if !now.Before(fault.ExpiresAt) {
+			delete(f.faults, id)
+		}
+	}
+}
+
+// match 返回本次触发的故障，按创建时间排序并累计触发次数
+func (f *FaultInjector) match(matches func(*FaultInjection) bool) []FaultInjection {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.faults) == 0 {
+		return nil
+	}
+	f.pruneLocked()
+	var triggered []FaultInjection
+	for _, fault := range f.faults {
+		if !matches(fault) || (fault.Probability < 1 && f.random() >= fault.Probability) {
+			continue
+		}
+		fault.Hits++
+		triggered = append(triggered, *fault)
+	}
+	sort.Slice(triggered, func(i, j int) bool { return triggered[i].CreatedAt.Before(triggered[j].CreatedAt) })
+	return triggered
+}
+
+// RouteFaults 返回请求触发的路由故障，path 为请求路径，route 为注册的路由模板
+func (f *FaultInjector) RouteFaults(method, path, route string) []FaultInjection {
+	return f.match(func(fault *FaultInjection) bool {
+		if fault.Path == "" || (fault.Method != "" && fault.Method != method) {
+			return false
+		}
+		return matchFaultPath(fault.Path, path) || (route != "" && matchFaultPath(fault.Path, route))
+	})
+}
+
+// matchFaultPath 以 * 结尾时按前缀匹配，否则完全匹配
+func matchFaultPath(pattern, path string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(path, prefix)
+	}
+	return pattern == path
+}
+
+// InjectService 执行依赖故障：先等待注入的延迟，再按故障类型返回错误
+// 注入器为 nil 或没有匹配的故障时直接返回 nil；ctx 取消时停止等待并返回 ctx 的错误
+func (f *FaultInjector) InjectService(ctx context.Context, service string) error {
+	faults := f.match(func(fault *FaultInjection) bool { return fault.Service == service })
+	for _, fault := range faults {
+		if err := sleepContext(ctx, fault.Latency()); err != nil {
+			return err
+		}
+	}
+	for _, fault := range faults {
+		switch fault.Type {
+		case FaultDBDisconnect:
+			return fmt.Errorf("%w（%s）: %w", ErrFaultInjected, fault.ID, driver.ErrBadConn)
+		case FaultRedisTimeout:
+			return &faultTimeoutError{id: fault.ID}
+		case FaultError:
+			return fmt.Errorf("%w（%s）: %s", ErrFaultInjected, fault.ID, fault.Message)
+		}
+	}
+	return nil
+}
+
+// sleepContext 等待指定时间，ctx 取消时提前返回
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// faultTimeoutError 注入的Redis超时，实现 net.Error 以便调用方按超时处理
+type faultTimeoutError struct {
+	id string
+}
+
+func (e *faultTimeoutError) Error() string {
+	return fmt.Sprintf("%v（%s）: i/o timeout", ErrFaultInjected, e.id)
+}
+
+func (e *faultTimeoutError) Timeout() bool   { return true }
+func (e *faultTimeoutError) Temporary() bool { return true }
+func (e *faultTimeoutError) Unwrap() error   { return ErrFaultInjected }
+
+// InstallGormCallbacks 在查询、写入和原生SQL执行前注入数据库故障
+// 回调把注入的错误写入 db.Error，GORM 不再执行SQL
+func (f *FaultInjector) InstallGormCallbacks(db *gorm.DB) error {
+	inject := func(tx *gorm.DB) {
+		if tx.Error != nil {
+			return
+		}
+		if err := f.InjectService(tx.Statement.Context, FaultServiceDatabase); err != nil {
+			tx.AddError(err)
+		}
+	}
+	callbacks := db.Callback()
+	for name, err := range map[string]error{
+		"query":  callbacks.Query().Before("gorm:query").Register("fault_injection:query", inject),
+		"create": callbacks.Create().Before("gorm:begin_transaction").Register("fault_injection:create", inject),
+		"update": callbacks.Update().Before("gorm:begin_transaction").Register("fault_injection:update", inject),
+		"delete": callbacks.Delete().Before("gorm:begin_transaction").Register("fault_injection:delete", inject),
+		"row":    callbacks.Row().Before("gorm:row").Register("fault_injection:row", inject),
+		"raw":    callbacks.Raw().Before("gorm:raw").Register("fault_injection:raw", inject),
+	} {
+		if err != nil {
+			return fmt.Errorf("注册 %s 故障注入回调失败: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// FaultInjectionRedisHook Redis客户端钩子，在命令执行前注入全局故障注入器中的Redis故障
+// 钩子在创建客户端时添加，故障注入未启用时只多一次读锁
+type FaultInjectionRedisHook struct{}
+
+// DialHook 不处理建立连接
+func (FaultInjectionRedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook 在单条命令执行前注入故障
+func (FaultInjectionRedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if err := DefaultFaultInjector().InjectService(ctx, FaultServiceRedis); err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+		return next(ctx, cmd)
+	}
+}
+
+// ProcessPipelineHook 在管道执行前注入故障，管道中的命令都返回注入的错误
+func (FaultInjectionRedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if err := DefaultFaultInjector().InjectService(ctx, FaultServiceRedis); err != nil {
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+		return next(ctx, cmds)
+	}
+}
+
+var (
+	defaultFaultInjector   *FaultInjector
+	defaultFaultInjectorMu sync.RWMutex
+)
+
+// SetDefaultFaultInjector 设置全局故障注入器，Redis客户端钩子使用该注入器
+func SetDefaultFaultInjector(injector *FaultInjector) {
+	defaultFaultInjectorMu.Lock()
+	defer defaultFaultInjectorMu.Unlock()
+	defaultFaultInjector = injector
+}
+
+// DefaultFaultInjector 获取全局故障注入器，未启用时返回 nil
+func DefaultFaultInjector() *FaultInjector {
+	defaultFaultInjectorMu.RLock()
+	defer defaultFaultInjectorMu.RUnlock()
+	return defaultFaultInjector
+}
@@ -0,0 +1,365 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// gormStatementKey 上下文中当前GORM语句的键，由 GormLogger 注册的回调写入
+type gormStatementKey struct{}
+
+// gormLoggerCallback 注册到各GORM回调处理器的回调名称
+const gormLoggerCallback = "log_manager:statement"
+
+// SQLModelStats 按模型统计的SQL语句
+type SQLModelStats struct {
+	Model         string  `json:"model"` // 模型名称，没有模型时为表名，原生SQL没有表名时为 raw
+	Table         string  `json:"table,omitempty"`
+	Selects       int64   `json:"selects"`
+	Inserts       int64   `json:"inserts"`
+	Updates       int64   `json:"updates"`
+	Deletes       int64   `json:"deletes"`
+	Others        int64   `json:"others"` // DDL、事务等其他语句
+	Errors        int64   `json:"errors"`
+	SlowQueries   int64   `json:"slow_queries"`
+	Rows          int64   `json:"rows"`
+	TotalMs       float64 `json:"total_ms"`
+	MaxMs         float64 `json:"max_ms"`
+	AvgMs         float64 `json:"avg_ms"`
+	LastStatement string  `json:"last_statement,omitempty"` // 最近一条慢查询或错误语句
+}
+
+// GormLogger GORM日志适配器
+//
+// 功能说明：
+// 1. 实现 gorm logger.Interface，所有SQL（包括迁移和后台服务）通过 LogSQL 写入SQL日志
+// 2. 日志附带请求ID、关联ID和用户ID，查询需要使用 db.WithContext(ctx) 传入请求上下文
+// 3. 超过 SQL_LOG_SLOW_THRESHOLD 的语句标记为慢查询并以警告级别记录
+// 4. 同时实现 gorm.Plugin，注册回调记录当前语句，按模型统计语句数、耗时、错误和慢查询
+// 5. 请求上下文中有调试记录器时，语句同时写入记录器（不受日志级别影响）
+//
+// 注意事项：
+// - SQL_LOG_INCLUDE_PARAMS=false 时记录带占位符的语句，不记录参数值
+// - 记录不存在（gorm.ErrRecordNotFound）不视为错误
+// - 统计不受日志级别影响，SQL日志未启用时仍然统计
+type GormLogger struct {
+	manager *LogManagerService
+	level   logger.LogLevel
+	stats   *sqlModelStatsTable
+}
+
+// sqlModelStatsTable 各模型的语句统计，LogMode 返回的副本共享同一张表
+type sqlModelStatsTable struct {
+	mu     sync.Mutex
+	models map[string]*SQLModelStats
+}
+
+// NewGormLogger 创建GORM日志适配器
+func NewGormLogger(manager *LogManagerService) *GormLogger {
+	return &GormLogger{
+		manager: manager,
+		level:   logger.Info,
+		stats:   &sqlModelStatsTable{models: make(map[string]*SQLModelStats)},
+	}
+}
+
+// GormLogger 返回日志管理器的GORM日志适配器，用于设置数据库连接的日志记录器
+func (s *LogManagerService) GormLogger() logger.Interface {
+	if s.gormLogger == nil {
+		return nil
+	}
+	return s.gormLogger
+}
+
+// SQLStats 返回按模型的SQL语句统计
+func (s *LogManagerService) SQLStats() []SQLModelStats {
+	if s.gormLogger == nil {
+		return []SQLModelStats{}
+	}
+	return s.gormLogger.Stats()
+}
+
+// Name 实现 gorm.Plugin
+func (l *GormLogger) Name() string {
+	return "log_manager_sql_logger"
+}
+
+// Initialize 实现 gorm.Plugin，在各回调处理器最前面记录当前语句，供 Trace 取得模型和表名
+func (l *GormLogger) Initialize(db *gorm.DB) error {
+	record := func(tx *gorm.DB) {
+		ctx := tx.Statement.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		// 同一个语句多次执行（如链式查询后先 Count 再 Find）时不重复包装上下文
+		if current, _ := ctx.Value(gormStatementKey{}).(*gorm.Statement); current == tx.Statement {
+			return
+		}
+		tx.Statement.Context = context.WithValue(ctx, gormStatementKey{}, tx.Statement)
+	}
+	callbacks := db.Callback()
+	for _, register := range []func() error{
+		func() error { return callbacks.Create().Before("*").Register(gormLoggerCallback, record) },
+		func() error { return callbacks.Query().Before("*").Register(gormLoggerCallback, record) },
+		func() error { return callbacks.Update().Before("*").Register(gormLoggerCallback, record) },
+		func() error { return callbacks.Delete().Before("*").Register(gormLoggerCallback, record) },
+		func() error { return callbacks.Row().Before("*").Register(gormLoggerCallback, record) },
+		func() error { return callbacks.Raw().Before("*").Register(gormLoggerCallback, record) },
+	} {
+		if err := register(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Install 把适配器设置为数据库连接的日志记录器并注册语句回调
+func (l *GormLogger) Install(db *gorm.DB) error {
+	db.Logger = l
+	return db.Use(l)
+}
+
+// LogMode 实现 logger.Interface，返回指定级别的副本，统计与原适配器共享
+func (l *GormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	copied := *l
+	copied.level = level
+	return &copied
+}
+
+// Info 实现 logger.Interface
+func (l *GormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Info {
+		l.logMessage(ctx, Config.LogLevelInfo, msg, data)
+	}
+}
+
+// Warn 实现 logger.Interface
+func (l *GormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Warn {
+		l.logMessage(ctx, Config.LogLevelWarning, msg, data)
+	}
+}
+
+// Error 实现 logger.Interface
+func (l *GormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Error {
+		l.logMessage(ctx, Config.LogLevelError, msg, data)
+	}
+}
+
+// logMessage 记录GORM自身的消息（如迁移器的提示）
+func (l *GormLogger) logMessage(ctx context.Context, level Config.LogLevel, msg string, data []interface{}) {
+	if !l.manager.config.SQLLog.Enabled {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	l.manager.LogWithContext(ctx, "sql", level, fmt.Sprintf(msg, data...), map[string]interface{}{
+		"source": "gorm",
+	})
+}
+
+// Trace 实现 logger.Interface，记录一条SQL语句并更新模型统计
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	duration := time.Since(begin)
+	sql, rows := fc()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+
+	config := l.manager.config.SQLLog
+	slow := config.SlowThreshold > 0 && duration > config.SlowThreshold
+	model, table := "raw", sqlTableName(sql)
+	var stmt *gorm.Statement
+	if ctx != nil {
+		stmt, _ = ctx.Value(gormStatementKey{}).(*gorm.Statement)
+	}
+	if stmt != nil {
+		if stmt.Table != "" {
+			table = stmt.Table
+		}
+		if !config.IncludeParams && stmt.SQL.Len() > 0 {
+			sql = stmt.SQL.String()
+		}
+	}
+	switch {
+	case stmt != nil && stmt.Schema != nil:
+		model = stmt.Schema.Name
+	case table != "":
+		model = table
+	}
+	operation := sqlOperation(sql)
+	l.stats.record(model, table, operation, duration, rows, err, slow, sql)
+	if recorder := DebugRecorderFromContext(ctx); recorder != nil {
+		query := DebugQuery{SQL: sql, Model: model, Table: table, Operation: operation, Rows: rows, DurationMs: debugMs(duration)}
+		if err != nil {
+			query.Error = err.Error()
+		}
+		recorder.RecordQuery(query, begin)
+	}
+
+	if l.level == logger.Silent || !config.Enabled {
+		return
+	}
+	if err == nil && !slow && l.level < logger.Info {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if max := config.MaxQuerySize * 1024; max > 0 && len(sql) > max {
+		sql = sql[:max] + "... [TRUNCATED]"
+	}
+	fields := map[string]interface{}{
+		"source":    "gorm",
+		"model":     model,
+		"operation": operation,
+	}
+	if table != "" {
+		fields["table"] = table
+	}
+	l.manager.LogSQL(ctx, sql, duration, rows, err, fields)
+}
+
+// Stats 返回按模型的语句统计，按总耗时从高到低排序
+func (l *GormLogger) Stats() []SQLModelStats {
+	l.stats.mu.Lock()
+	defer l.stats.mu.Unlock()
+
+	stats := make([]SQLModelStats, 0, len(l.stats.models))
+	for _, model := range l.stats.models {
+		copied := *model
+		if total := copied.Selects + copied.Inserts + copied.Updates + copied.Deletes + copied.Others; total > 0 {
+			copied.AvgMs = copied.TotalMs / float64(total)
+		}
+		stats = append(stats, copied)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].TotalMs != stats[j].TotalMs {
+			return stats[i].TotalMs > stats[j].TotalMs
+		}
+		return stats[i].Model < stats[j].Model
+	})
+	return stats
+}
+
+// ResetStats 清空语句统计
+func (l *GormLogger) ResetStats() {
+	l.stats.mu.Lock()
+	l.stats.models = make(map[string]*SQLModelStats)
+	l.stats.mu.Unlock()
+}
+
+// record 更新模型统计
+func (t *sqlModelStatsTable) record(model, table, operation string, duration time.Duration, rows int64, err error, slow bool, sql string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats, exists := t.models[model]
+	if !exists {
+		stats = &SQLModelStats{Model: model, Table: table}
+		t.models[model] = stats
+	}
+	switch operation {
+	case "select":
+		stats.Selects++
+	case "insert":
+		stats.Inserts++
+	case "update":
+		stats.Updates++
+	case "delete":
+		stats.Deletes++
+	default:
+		stats.Others++
+	}
+	if rows > 0 {
+		stats.Rows += rows
+	}
+	ms := float64(duration.Microseconds()) / 1000
+	stats.TotalMs += ms
+	if ms > stats.MaxMs {
+		stats.MaxMs = ms
+	}
+	if err != nil {
+		stats.Errors++
+	}
+	if slow {
+		stats.SlowQueries++
+	}
+	if err != nil || slow {
+		if len(sql) > 512 {
+			sql = sql[:512] + "..."
+		}
+		stats.LastStatement = sql
+	}
+}
+
+// sqlOperation 按语句的第一个关键字判断操作类型：select、insert、update、delete 或 other
+func sqlOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "other"
+	}
+	keyword := strings.ToLower(fields[0])
+	if keyword == "with" {
+		// CTE 按主语句判断
+		for _, field := range fields[1:] {
+			switch lower := strings.ToLower(field); lower {
+			case "select", "insert", "update", "delete":
+				keyword = lower
+			}
+		}
+	}
+	switch keyword {
+	case "select", "insert", "update", "delete":
+		return keyword
+	case "replace":
+		return "insert"
+	}
+	return "other"
+}
+
+// sqlTableName 从原生SQL中取出第一个表名，无法识别时返回空字符串
+func sqlTableName(sql string) string {
+	fields := strings.Fields(sql)
+	for i := 0; i < len(fields)-1; i++ {
+		switch strings.ToLower(fields[i]) {
+		case "from", "into", "update", "table":
+			j := i + 1
+			for j < len(fields)-1 && isSQLTableModifier(fields[j]) {
+				j++
+			}
+			if strings.HasPrefix(fields[j], "(") {
+				continue
+			}
+			name := strings.Trim(fields[j], "`\"[]();,")
+			if name == "" {
+				continue
+			}
+			if dot := strings.LastIndexByte(name, '.'); dot >= 0 {
+				name = strings.Trim(name[dot+1:], "`\"[]")
+			}
+			return name
+		}
+	}
+	return ""
+}
+
+// isSQLTableModifier 表名前的修饰词，如 CREATE TABLE IF NOT EXISTS、SELECT ... FROM ONLY
+func isSQLTableModifier(word string) bool {
+	switch strings.ToLower(word) {
+	case "if", "not", "exists", "only", "ignore":
+		return true
+	}
+	return false
+}
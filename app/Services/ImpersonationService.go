@@ -0,0 +1,351 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Utils"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrImpersonationDisabled 未启用模拟登录
+	ErrImpersonationDisabled = errors.New("未启用管理员模拟登录")
+	// ErrImpersonationUserNotFound 被模拟的用户不存在
+	ErrImpersonationUserNotFound = errors.New("被模拟的用户不存在")
+	// ErrImpersonationForbidden 不能模拟自己、其他管理员或已禁用的用户
+	ErrImpersonationForbidden = errors.New("不能模拟自己、管理员或已禁用的用户")
+	// ErrImpersonationDuration 模拟有效期超出范围
+	ErrImpersonationDuration = errors.New("模拟有效期超出允许范围")
+	// ErrImpersonationNotFound 模拟会话不存在
+	ErrImpersonationNotFound = errors.New("模拟会话不存在")
+	// ErrImpersonationEnded 模拟会话已结束或已过期
+	ErrImpersonationEnded = errors.New("模拟会话已结束")
+	// ErrImpersonationRestricted 模拟期间不允许的敏感操作
+	ErrImpersonationRestricted = errors.New("模拟登录期间不允许该操作")
+	// ErrImpersonationReadOnly 只读模拟会话不允许修改数据
+	ErrImpersonationReadOnly = errors.New("只读模拟会话不允许修改数据")
+)
+
+// ImpersonationRequest 发起模拟登录的参数
+type ImpersonationRequest struct {
+	TargetUserID  uint
+	Reason        string
+	Duration      time.Duration // 为0时使用默认有效期
+	ReadOnly      bool
+	IPAddress     string
+	RequestID     string
+	CorrelationID string
+}
+
+// ImpersonationResult 模拟登录结果，Token 只在创建时返回
+type ImpersonationResult struct {
+	Token   string                       `json:"token"`
+	Session *Models.ImpersonationSession `json:"session"`
+}
+
+// ImpersonatedRequest 模拟期间的一次请求，用于记录审计日志
+type ImpersonatedRequest struct {
+	Method        string
+	Path          string
+	Status        int
+	IPAddress     string
+	UserAgent     string
+	RequestID     string
+	CorrelationID string
+}
+
+// ImpersonationService 管理员模拟登录服务
+// 功能说明：
+// 1. 管理员以普通用户身份签发限时模拟令牌，会话保存在数据库中，可以提前结束
+// 2. 模拟期间每个请求写入带模拟管理员标记的审计日志，敏感操作和只读会话的写操作被拒绝
+// 3. 开始和结束模拟时记录审计日志并通过站内通知告知被模拟的用户
+type ImpersonationService struct {
+	db     *gorm.DB
+	config *Config.ImpersonationConfig
+	now    func() time.Time
+}
+
+// NewImpersonationService 创建模拟登录服务
+//
+// config 为 nil 时使用全局配置，未加载配置时使用默认值。
+func NewImpersonationService(db *gorm.DB, config *Config.ImpersonationConfig) *ImpersonationService {
+	if config == nil {
+		config = Config.GetImpersonationConfig()
+	}
+	if config == nil {
+		config = &Config.ImpersonationConfig{
+			Enabled:          true,
+			DefaultTTL:       30 * time.Minute,
+			MaxTTL:           2 * time.Hour,
+			RestrictedRoutes: "POST /api/v1/auth/change-password,POST /api/v1/auth/mfa/*,POST /api/v1/api-keys*",
+		}
+	}
+	return &ImpersonationService{db: db, config: config, now: time.Now}
+}
+
+// SetClock 设置时钟，用于测试
+func (s *ImpersonationService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// Start 以目标用户身份签发模拟令牌
+// 只能模拟已启用的非管理员用户，有效期不能超过配置的最长有效期
+func (s *ImpersonationService) Start(impersonatorID uint, req ImpersonationRequest) (*ImpersonationResult, error) {
+	if !s.config.Enabled {
+		return nil, ErrImpersonationDisabled
+	}
+	duration := req.Duration
+	if duration == 0 {
+		duration = s.config.DefaultTTL
+	}
+	if duration < 0 || duration > s.config.MaxTTL {
+		return nil, ErrImpersonationDuration
+	}
+
+	var admin Models.User
+	if err := s.db.First(&admin, impersonatorID).Error; err != nil {
+		return nil, fmt.Errorf("查询管理员失败: %w", err)
+	}
+	var target Models.User
+	if err := s.db.First(&target, req.TargetUserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrImpersonationUserNotFound
+		}
+		return nil, err
+	}
+	if target.ID == admin.ID || target.Role == "admin" || !target.IsActive() {
+		return nil, ErrImpersonationForbidden
+	}
+
+	jwtUtils := Utils.GetGlobalJWTUtils()
+	if jwtUtils == nil {
+		return nil, errors.New("JWT工具未初始化")
+	}
+
+	now := s.now()
+	session := &Models.ImpersonationSession{
+		SessionID:        uuid.NewString(),
+		ImpersonatorID:   admin.ID,
+		ImpersonatorName: admin.Username,
+		TargetUserID:     target.ID,
+		TargetUsername:   target.Username,
+		Reason:           req.Reason,
+		ReadOnly:         req.ReadOnly,
+		IPAddress:        req.IPAddress,
+		ExpiresAt:        now.Add(duration),
+	}
+	token, err := jwtUtils.GenerateImpersonationToken(target.ID, target.Username, target.Email, target.Role,
+		session.SessionID, admin.ID, admin.Username, session.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(session).Error; err != nil {
+		return nil, fmt.Errorf("保存模拟会话失败: %w", err)
+	}
+
+	s.audit(Models.NewAuditLog(admin.ID, admin.Username, Models.AuditActionImpersonationStart, "user", target.ID).
+		SetLevel(Models.AuditLevelWarning).
+		SetImpersonator(admin.ID).
+		SetIPAddress(req.IPAddress).
+		SetRequestID(req.RequestID).
+		SetCorrelationID(req.CorrelationID).
+		SetDescription(fmt.Sprintf("管理员 %s 开始模拟用户 %s，原因：%s", admin.Username, target.Username, req.Reason)).
+		SetMetadata(map[string]interface{}{
+			"session_id": session.SessionID,
+			"read_only":  session.ReadOnly,
+			"expires_at": session.ExpiresAt,
+		}))
+	s.notify(session, "管理员正在以你的身份登录",
+		fmt.Sprintf("管理员 %s 开始以你的身份访问系统，原因：%s，有效期至 %s。如有疑问请联系管理员。",
+			admin.Username, req.Reason, session.ExpiresAt.Format("2006-01-02 15:04:05")))
+
+	return &ImpersonationResult{Token: token, Session: session}, nil
+}
+
+// End 结束模拟会话，会话的模拟令牌立即失效
+func (s *ImpersonationService) End(id, endedBy uint, endedByName string) (*Models.ImpersonationSession, error) {
+	var session Models.ImpersonationSession
+	if err := s.db.First(&session, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrImpersonationNotFound
+		}
+		return nil, err
+	}
+	now := s.now()
+	if !session.IsActive(now) {
+		return nil, ErrImpersonationEnded
+	}
+
+	result := s.db.Model(&Models.ImpersonationSession{}).
+		Where("id = ? AND ended_at IS NULL", session.ID).
+		Updates(map[string]interface{}{"ended_at": now, "ended_by": endedBy})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrImpersonationEnded
+	}
+	session.EndedAt = &now
+	session.EndedBy = endedBy
+
+	s.audit(Models.NewAuditLog(endedBy, endedByName, Models.AuditActionImpersonationEnd, "user", session.TargetUserID).
+		SetImpersonator(session.ImpersonatorID).
+		SetDescription(fmt.Sprintf("结束管理员 %s 对用户 %s 的模拟，期间共 %d 个请求",
+			session.ImpersonatorName, session.TargetUsername, session.RequestCount)).
+		SetMetadata(map[string]interface{}{"session_id": session.SessionID}))
+	s.notify(&session, "管理员已结束以你的身份登录",
+		fmt.Sprintf("管理员 %s 以你的身份访问系统的会话已结束，期间共 %d 个请求。", session.ImpersonatorName, session.RequestCount))
+
+	return &session, nil
+}
+
+// List 按列表查询分页获取模拟会话，activeOnly 为 true 时只返回未结束且未过期的会话
+func (s *ImpersonationService) List(q *Utils.ListQuery, req Utils.PageRequest, activeOnly bool) ([]Models.ImpersonationSession, Utils.PageMeta, error) {
+	query := s.db.Model(&Models.ImpersonationSession{})
+	if activeOnly {
+		query = query.Where("ended_at IS NULL AND expires_at > ?", s.now())
+	}
+	query, order, err := q.Apply(query, req)
+	if err != nil {
+		return nil, Utils.PageMeta{}, err
+	}
+	var sessions []Models.ImpersonationSession
+	meta, err := Utils.Paginate(query, req, order, &sessions)
+	return sessions, meta, err
+}
+
+// ActiveSession 获取模拟令牌对应的会话，会话已结束或已过期时返回 ErrImpersonationEnded
+func (s *ImpersonationService) ActiveSession(sessionID string) (*Models.ImpersonationSession, error) {
+	var session Models.ImpersonationSession
+	if err := s.db.Where("session_id = ?", sessionID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrImpersonationEnded
+		}
+		return nil, err
+	}
+	if !session.IsActive(s.now()) {
+		return nil, ErrImpersonationEnded
+	}
+	return &session, nil
+}
+
+// Authorize 检查模拟期间是否允许该请求
+// route 为 gin 注册的路由路径（如 /api/v1/auth/change-password），未匹配路由时为请求路径
+func (s *ImpersonationService) Authorize(session *Models.ImpersonationSession, method, route string) error {
+	for _, restricted := range s.config.RestrictedRouteList() {
+		fields := strings.Fields(restricted)
+		if len(fields) != 2 {
+			continue
+		}
+		if fields[0] != "*" && !strings.EqualFold(fields[0], method) {
+			continue
+		}
+		if matchImpersonationRoute(fields[1], route) {
+			return ErrImpersonationRestricted
+		}
+	}
+	if session.ReadOnly {
+		switch method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			return ErrImpersonationReadOnly
+		}
+	}
+	return nil
+}
+
+// matchImpersonationRoute 路径以 * 结尾时按前缀匹配，否则要求完全相同
+func matchImpersonationRoute(pattern, route string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(route, prefix)
+	}
+	return route == pattern
+}
+
+// RecordRequest 记录模拟期间的一次请求，审计日志以被模拟用户为操作人并标记模拟的管理员
+func (s *ImpersonationService) RecordRequest(session *Models.ImpersonationSession, req ImpersonatedRequest) {
+	now := s.now()
+	s.db.Model(&Models.ImpersonationSession{}).Where("id = ?", session.ID).UpdateColumns(map[string]interface{}{
+		"request_count":   gorm.Expr("request_count + ?", 1),
+		"last_request_at": now,
+	})
+
+	entry := Models.NewAuditLog(session.TargetUserID, session.TargetUsername, Models.AuditActionImpersonationRequest, "impersonation", session.ID).
+		SetImpersonator(session.ImpersonatorID).
+		SetIPAddress(req.IPAddress).
+		SetUserAgent(req.UserAgent).
+		SetRequestID(req.RequestID).
+		SetCorrelationID(req.CorrelationID).
+		SetDescription(fmt.Sprintf("[模拟登录] 管理员 %s 以用户 %s 身份请求 %s %s",
+			session.ImpersonatorName, session.TargetUsername, req.Method, req.Path)).
+		SetMetadata(map[string]interface{}{
+			"session_id":   session.SessionID,
+			"impersonator": session.ImpersonatorName,
+			"method":       req.Method,
+			"path":         req.Path,
+			"status":       req.Status,
+		})
+	if req.Status >= http.StatusBadRequest {
+		entry.Status = Models.AuditStatusFailed
+		entry.Level = Models.AuditLevelWarning
+	}
+	entry.CreatedAt = now
+	s.audit(entry)
+}
+
+// audit 保存审计日志，保存失败不影响模拟登录
+func (s *ImpersonationService) audit(entry *Models.AuditLog) {
+	if err := s.db.Create(entry).Error; err != nil {
+		log.Printf("保存模拟登录审计日志失败: %v", err)
+	}
+}
+
+// notify 通过站内通知告知被模拟的用户，未初始化全局通知服务时使用当前数据库创建
+func (s *ImpersonationService) notify(session *Models.ImpersonationSession, title, content string) {
+	notifier := DefaultNotificationService()
+	if notifier == nil {
+		notifier = NewNotificationService(s.db, nil)
+	}
+	_, err := notifier.Notify(session.TargetUserID, Models.NotificationTypeImpersonation, title, content, map[string]interface{}{
+		"session_id":   session.SessionID,
+		"impersonator": session.ImpersonatorName,
+		"expires_at":   session.ExpiresAt,
+	})
+	if err != nil {
+		log.Printf("发送模拟登录通知失败: %v", err)
+	}
+}
+
+var (
+	defaultImpersonationService   *ImpersonationService
+	defaultImpersonationServiceMu sync.RWMutex
+)
+
+// SetDefaultImpersonationService 设置全局模拟登录服务
+func SetDefaultImpersonationService(service *ImpersonationService) {
+	defaultImpersonationServiceMu.Lock()
+	defer defaultImpersonationServiceMu.Unlock()
+	defaultImpersonationService = service
+}
+
+// DefaultImpersonationService 获取全局模拟登录服务，未设置时使用 db 创建，db 为 nil 时返回 nil
+func DefaultImpersonationService(db *gorm.DB) *ImpersonationService {
+	defaultImpersonationServiceMu.RLock()
+	defer defaultImpersonationServiceMu.RUnlock()
+	if defaultImpersonationService != nil {
+		return defaultImpersonationService
+	}
+	if db == nil {
+		return nil
+	}
+	return NewImpersonationService(db, nil)
+}
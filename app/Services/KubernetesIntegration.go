@@ -0,0 +1,479 @@
+package Services
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrExternalMetricNotFound = errors.New("外部指标不存在")
+	ErrInvalidLabelSelector   = errors.New("标签选择器无效")
+)
+
+// KubernetesMetadata 当前Pod的部署信息
+type KubernetesMetadata struct {
+	InCluster bool   `json:"in_cluster"` // 是否运行在Kubernetes集群中（存在 KUBERNETES_SERVICE_HOST）
+	PodName   string `json:"pod_name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	NodeName  string `json:"node_name,omitempty"`
+	PodIP     string `json:"pod_ip,omitempty"`
+}
+
+// Labels 返回附加到指标和日志的部署标签，只包含已识别的字段
+func (m KubernetesMetadata) Labels() map[string]string {
+	labels := make(map[string]string)
+	if m.PodName != "" {
+		labels["pod"] = m.PodName
+	}
+	if m.Namespace != "" {
+		labels["namespace"] = m.Namespace
+	}
+	if m.NodeName != "" {
+		labels["node"] = m.NodeName
+	}
+	return labels
+}
+
+// DetectKubernetesMetadata 识别当前Pod的部署信息
+// 优先使用Downward API注入的 POD_NAME、POD_NAMESPACE、NODE_NAME、POD_IP；
+// 未注入时Pod名称取主机名（Pod默认主机名即Pod名称），命名空间取服务账号目录下的 namespace 文件
+func DetectKubernetesMetadata(serviceAccountPath string) KubernetesMetadata {
+	metadata := KubernetesMetadata{
+		InCluster: os.Getenv("KUBERNETES_SERVICE_HOST") != "",
+		PodName:   os.Getenv("POD_NAME"),
+		Namespace: os.Getenv("POD_NAMESPACE"),
+		NodeName:  os.Getenv("NODE_NAME"),
+		PodIP:     os.Getenv("POD_IP"),
+	}
+	if metadata.PodName == "" && metadata.InCluster {
+		metadata.PodName, _ = os.Hostname()
+	}
+	if metadata.Namespace == "" && serviceAccountPath != "" {
+		if data, err := os.ReadFile(filepath.Join(serviceAccountPath, "namespace")); err == nil {
+			metadata.Namespace = strings.TrimSpace(string(data))
+		}
+	}
+	return metadata
+}
+
+// ExternalMetricValue external.metrics.k8s.io/v1beta1 的指标值
+type ExternalMetricValue struct {
+	MetricName   string            `json:"metricName"`
+	MetricLabels map[string]string `json:"metricLabels"`
+	Timestamp    time.Time         `json:"timestamp"`
+	Value        string            `json:"value"` // Kubernetes数量格式，如 42、1500m
+}
+
+// KubernetesReadinessGateStatus readiness gate条件的同步状态
+type KubernetesReadinessGateStatus struct {
+	ConditionType string    `json:"condition_type"`
+	Status        string    `json:"status,omitempty"` // 最近一次写入的条件状态：True 或 False
+	Message       string    `json:"message,omitempty"`
+	LastSyncedAt  time.Time `json:"last_synced_at,omitempty"`
+	LastError     string    `json:"last_error,omitempty"`
+}
+
+// KubernetesIntegration Kubernetes集成
+// 功能说明：
+// 1. 识别Pod、命名空间和节点，作为部署标签附加到指标和日志
+// 2. 按同步间隔把健康检查框架的就绪结果写入Pod的readiness gate条件（PATCH pods/status），条件未就绪时Pod不接收Service流量
+// 3. 按 external.metrics.k8s.io 格式提供监控核心中允许的指标（如队列深度），供HPA按业务负载扩缩容
+// 4. 直接调用API Server的REST接口，使用服务账号令牌和CA证书认证，不依赖client-go
+type KubernetesIntegration struct {
+	metadata           KubernetesMetadata
+	serviceAccountPath string
+	gateType           string
+	externalMetrics    []string
+	core               *MonitoringCore
+	probe              func() error
+
+	apiServer string
+	token     string // 为空时每次请求从服务账号目录读取，兼容令牌轮换
+	client    *http.Client
+
+	mu         sync.Mutex
+	gateStatus KubernetesReadinessGateStatus
+}
+
+// NewKubernetesIntegration 创建Kubernetes集成
+func NewKubernetesIntegration(config *Config.MonitoringConfig) *KubernetesIntegration {
+	if config == nil {
+		config = &Config.MonitoringConfig{}
+		config.SetDefaults()
+		if globalConfig := Config.GetConfig(); globalConfig != nil {
+			config = &globalConfig.Monitoring
+		}
+	}
+
+	kubernetes := config.Kubernetes
+	integration := &KubernetesIntegration{
+		metadata:           DetectKubernetesMetadata(kubernetes.ServiceAccountPath),
+		serviceAccountPath: kubernetes.ServiceAccountPath,
+		gateType:           kubernetes.ReadinessGateType,
+		externalMetrics:    splitNotificationList(kubernetes.ExternalMetrics),
+		core:               DefaultMonitoringCore(),
+		gateStatus:         KubernetesReadinessGateStatus{ConditionType: kubernetes.ReadinessGateType},
+	}
+	if host := os.Getenv("KUBERNETES_SERVICE_HOST"); host != "" {
+		port := os.Getenv("KUBERNETES_SERVICE_PORT")
+		if port == "" {
+			port = "443"
+		}
+		integration.apiServer = "https://" + net.JoinHostPort(host, port)
+		integration.client = newKubernetesHTTPClient(kubernetes.ServiceAccountPath)
+	}
+	return integration
+}
+
+// newKubernetesHTTPClient 创建信任服务账号CA证书的HTTP客户端
+func newKubernetesHTTPClient(serviceAccountPath string) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if data, err := os.ReadFile(filepath.Join(serviceAccountPath, "ca.crt")); err == nil {
+		roots := x509.NewCertPool()
+		if roots.AppendCertsFromPEM(data) {
+			transport.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+		}
+	}
+	return &http.Client{Transport: transport, Timeout: 10 * time.Second}
+}
+
+// SetAPIClient 设置API Server地址、令牌和HTTP客户端，用于集群外运行或测试
+func (k *KubernetesIntegration) SetAPIClient(apiServer, token string, client *http.Client) {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	k.apiServer = strings.TrimRight(apiServer, "/")
+	k.token = token
+	k.client = client
+}
+
+// SetMonitoringCore 设置外部指标的数据来源
+func (k *KubernetesIntegration) SetMonitoringCore(core *MonitoringCore) {
+	k.core = core
+}
+
+// SetReadinessProbe 设置readiness gate使用的就绪检查，返回错误时条件置为False
+func (k *KubernetesIntegration) SetReadinessProbe(probe func() error) {
+	k.probe = probe
+}
+
+// SetMetadata 覆盖识别到的部署信息
+func (k *KubernetesIntegration) SetMetadata(metadata KubernetesMetadata) {
+	k.metadata = metadata
+}
+
+// Metadata 返回当前Pod的部署信息
+func (k *KubernetesIntegration) Metadata() KubernetesMetadata {
+	return k.metadata
+}
+
+// Labels 返回附加到指标和日志的部署标签
+func (k *KubernetesIntegration) Labels() map[string]string {
+	return k.metadata.Labels()
+}
+
+// ExternalMetricNames 返回允许通过外部指标接口提供的指标名
+func (k *KubernetesIntegration) ExternalMetricNames() []string {
+	return append([]string(nil), k.externalMetrics...)
+}
+
+// ReadinessGateStatus 返回readiness gate条件的同步状态
+func (k *KubernetesIntegration) ReadinessGateStatus() KubernetesReadinessGateStatus {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.gateStatus
+}
+
+// SyncReadinessGate 执行就绪检查并把结果写入Pod的readiness gate条件
+// 未配置条件类型时不做任何事；条件状态和消息与上次写入一致时跳过
+func (k *KubernetesIntegration) SyncReadinessGate(ctx context.Context) error {
+	if k.gateType == "" {
+		return nil
+	}
+	if k.apiServer == "" {
+		return fmt.Errorf("未在Kubernetes集群中运行，无法同步readiness gate")
+	}
+	if k.metadata.PodName == "" || k.metadata.Namespace == "" {
+		return fmt.Errorf("无法确定Pod名称或命名空间，请通过Downward API注入 POD_NAME 和 POD_NAMESPACE")
+	}
+
+	status, reason, message := "True", "Ready", ""
+	if k.probe != nil {
+		if err := k.probe(); err != nil {
+			status, reason, message = "False", "NotReady", err.Error()
+		}
+	}
+
+	k.mu.Lock()
+	unchanged := k.gateStatus.Status == status && k.gateStatus.Message == message && k.gateStatus.LastError == ""
+	k.mu.Unlock()
+	if unchanged {
+		return nil
+	}
+
+	err := k.patchPodCondition(ctx, status, reason, message)
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.gateStatus.LastSyncedAt = time.Now()
+	if err != nil {
+		k.gateStatus.LastError = err.Error()
+		return err
+	}
+	k.gateStatus.Status = status
+	k.gateStatus.Message = message
+	k.gateStatus.LastError = ""
+	return nil
+}
+
+// patchPodCondition 以strategic merge patch更新Pod状态中的条件，按条件类型合并，不影响其他条件
+func (k *KubernetesIntegration) patchPodCondition(ctx context.Context, status, reason, message string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []map[string]interface{}{{
+				"type":               k.gateType,
+				"status":             status,
+				"reason":             reason,
+				"message":            message,
+				"lastTransitionTime": time.Now().UTC().Format(time.RFC3339),
+			}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/api/v1/namespaces/%s/pods/%s/status",
+		k.apiServer, url.PathEscape(k.metadata.Namespace), url.PathEscape(k.metadata.PodName))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/strategic-merge-patch+json")
+	req.Header.Set("Accept", "application/json")
+	token, err := k.bearerToken()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("更新Pod就绪条件失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("更新Pod就绪条件失败: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// bearerToken 返回访问API Server的令牌
+func (k *KubernetesIntegration) bearerToken() (string, error) {
+	if k.token != "" {
+		return k.token, nil
+	}
+	data, err := os.ReadFile(filepath.Join(k.serviceAccountPath, "token"))
+	if err != nil {
+		return "", fmt.Errorf("读取服务账号令牌失败: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// StartReadinessSync 立即同步一次readiness gate，之后按间隔同步，ctx 取消后停止
+func (k *KubernetesIntegration) StartReadinessSync(ctx context.Context, interval time.Duration) {
+	if k.gateType == "" || interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := k.SyncReadinessGate(ctx); err != nil {
+				log.Printf("同步Kubernetes readiness gate失败: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// ExternalMetrics 按 external.metrics.k8s.io 格式返回指标值
+// 功能说明：
+// 1. 只提供 ExternalMetrics 配置中列出的指标，其他指标视为不存在
+// 2. 识别到命名空间时只响应本命名空间的查询
+// 3. 监控核心中同名的各个序列（如 queue_depth{queue="emails"}）分别返回，按标签选择器过滤
+func (k *KubernetesIntegration) ExternalMetrics(namespace, metric, selector string) ([]ExternalMetricValue, error) {
+	if !containsString(k.externalMetrics, metric) {
+		return nil, ErrExternalMetricNotFound
+	}
+	if k.metadata.Namespace != "" && namespace != k.metadata.Namespace {
+		return nil, ErrExternalMetricNotFound
+	}
+	requirements, err := parseLabelSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	values := k.core.Values()
+	series := make([]string, 0)
+	for name := range values {
+		series = append(series, name)
+	}
+	sort.Strings(series)
+
+	items := make([]ExternalMetricValue, 0)
+	for _, name := range series {
+		metricName, labels := parseSeriesName(name)
+		if metricName != metric || !labelsMatch(labels, requirements) {
+			continue
+		}
+		items = append(items, ExternalMetricValue{
+			MetricName:   metric,
+			MetricLabels: labels,
+			Timestamp:    now,
+			Value:        formatKubernetesQuantity(values[name]),
+		})
+	}
+	return items, nil
+}
+
+// labelRequirement 标签选择器中的一个条件
+type labelRequirement struct {
+	key      string
+	operator string // =、!=、exists、!exists
+	value    string
+}
+
+// parseLabelSelector 解析基于等值的标签选择器，如 queue=emails,env!=dev,priority
+func parseLabelSelector(selector string) ([]labelRequirement, error) {
+	var requirements []labelRequirement
+	for _, item := range strings.Split(selector, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		var requirement labelRequirement
+		switch {
+		case strings.Contains(item, "!="):
+			parts := strings.SplitN(item, "!=", 2)
+			requirement = labelRequirement{key: parts[0], operator: "!=", value: parts[1]}
+		case strings.Contains(item, "=="):
+			parts := strings.SplitN(item, "==", 2)
+			requirement = labelRequirement{key: parts[0], operator: "=", value: parts[1]}
+		case strings.Contains(item, "="):
+			parts := strings.SplitN(item, "=", 2)
+			requirement = labelRequirement{key: parts[0], operator: "=", value: parts[1]}
+		case strings.HasPrefix(item, "!"):
+			requirement = labelRequirement{key: item[1:], operator: "!exists"}
+		default:
+			requirement = labelRequirement{key: item, operator: "exists"}
+		}
+		requirement.key = strings.TrimSpace(requirement.key)
+		requirement.value = strings.TrimSpace(requirement.value)
+		if requirement.key == "" || strings.ContainsAny(requirement.key, " ()") || strings.ContainsAny(requirement.value, " ()") {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidLabelSelector, item)
+		}
+		requirements = append(requirements, requirement)
+	}
+	return requirements, nil
+}
+
+// labelsMatch 标签是否满足全部条件
+func labelsMatch(labels map[string]string, requirements []labelRequirement) bool {
+	for _, requirement := range requirements {
+		value, exists := labels[requirement.key]
+		switch requirement.operator {
+		case "=":
+			if !exists || value != requirement.value {
+				return false
+			}
+		case "!=":
+			if exists && value == requirement.value {
+				return false
+			}
+		case "exists":
+			if !exists {
+				return false
+			}
+		case "!exists":
+			if exists {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// parseSeriesName 把 ingestSeriesName 生成的序列名拆分为指标名和标签
+func parseSeriesName(series string) (string, map[string]string) {
+	labels := make(map[string]string)
+	start := strings.IndexByte(series, '{')
+	if start < 0 || !strings.HasSuffix(series, "}") {
+		return series, labels
+	}
+
+	rest := series[start+1 : len(series)-1]
+	for rest != "" {
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 || eq+1 >= len(rest) || rest[eq+1] != '"' {
+			return series, map[string]string{}
+		}
+		key := rest[:eq]
+		// 找到未转义的结束引号
+		end := eq + 2
+		for end < len(rest) && rest[end] != '"' {
+			if rest[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(rest) {
+			return series, map[string]string{}
+		}
+		value, err := strconv.Unquote(rest[eq+1 : end+1])
+		if err != nil {
+			return series, map[string]string{}
+		}
+		labels[key] = value
+		rest = strings.TrimPrefix(rest[end+1:], ",")
+	}
+	return series[:start], labels
+}
+
+// formatKubernetesQuantity 按Kubernetes数量格式输出指标值，非整数使用毫单位
+func formatKubernetesQuantity(value float64) string {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return "0"
+	}
+	if value == math.Trunc(value) && math.Abs(value) < 1e15 {
+		return strconv.FormatInt(int64(value), 10)
+	}
+	return strconv.FormatInt(int64(math.Round(value*1000)), 10) + "m"
+}
@@ -2,6 +2,7 @@ package Services
 
 import (
 	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Utils"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -9,8 +10,10 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -21,9 +24,25 @@ type LogManagerService struct {
 	mu         sync.RWMutex
 	closed     bool
 	asyncQueue chan LogEntry
+	workers    sync.WaitGroup // 写入协程
 	stats      *LogStats
 	ctx        context.Context
 	cancel     context.CancelFunc
+	shipper    *LogShipper    // 日志集中投递，未启用时为nil
+	sampler    *logSampler    // 日志采样
+	masker     *LogMasker     // 敏感信息脱敏，未启用时为nil
+	reporter   *ErrorReporter // 错误上报（Sentry/Bugsnag），未启用时为nil
+
+	staticFields map[string]interface{} // 附加到每条日志的固定字段，如Kubernetes部署标签
+
+	overflowPolicies map[string]string // 按日志记录器覆盖的队列溢出处理方式
+	overflowSampled  uint64            // sample 策略的计数
+	pending          int64             // 已入队但尚未写入的日志数，用于 Flush
+
+	archiveStore  ArchiveObjectStore // 日志归档存储，为nil时不归档
+	maintenanceMu sync.Mutex         // 串行化日志文件的轮转、压缩和清理
+
+	gormLogger *GormLogger // GORM日志适配器，按模型统计SQL语句
 }
 
 // LogStats 日志统计信息
@@ -35,6 +54,22 @@ type LogStats struct {
 	Errors       int64                     `json:"errors"`
 	Performance  map[string]float64        `json:"performance"`
 	LastReset    time.Time                 `json:"last_reset"`
+
+	// 以下计数从服务启动开始累计，不随周期统计重置
+	Dropped         int64            `json:"dropped"`           // 队列已满被丢弃的日志数
+	DroppedByLogger map[string]int64 `json:"dropped_by_logger"` // 按日志记录器统计的丢弃数
+	Sampled         int64            `json:"sampled"`           // 被采样过滤的日志数
+	SampledByLogger map[string]int64 `json:"sampled_by_logger"` // 按日志记录器统计的采样过滤数
+	DroppedByPolicy map[string]int64 `json:"dropped_by_policy"` // 按队列溢出处理方式统计的丢弃数
+	Overflows       int64            `json:"overflows"`         // 入队时队列已满的次数
+	Blocked         int64            `json:"blocked"`           // 队列已满时调用者阻塞等待的次数
+	SyncWrites      int64            `json:"sync_writes"`       // 队列已满时同步写入的日志数
+	Batches         int64            `json:"batches"`           // 写入协程写入的批次数
+
+	// 以下为获取统计时的队列状态
+	QueueDepth    int `json:"queue_depth"`    // 队列中等待写入的日志数
+	QueueCapacity int `json:"queue_capacity"` // 队列容量
+	Workers       int `json:"workers"`        // 写入协程数
 }
 
 // Logger 日志记录器
@@ -91,6 +126,7 @@ type DailyRotateWriter struct {
 	config      Config.LogRotation
 	mu          sync.Mutex
 	file        *os.File
+	onRotate    func() // 日期变化切换文件后调用，用于压缩和清理旧文件
 }
 
 // Write 实现io.Writer接口
@@ -111,6 +147,9 @@ func (w *DailyRotateWriter) Write(p []byte) (n int, err error) {
 
 		// 更新当前文件路径
 		w.currentFile = expectedFile
+		if w.onRotate != nil {
+			go w.onRotate()
+		}
 	}
 
 	// 打开或创建新文件
@@ -131,6 +170,58 @@ func (w *DailyRotateWriter) Write(p []byte) (n int, err error) {
 	return w.file.Write(p)
 }
 
+// Rotate 手动轮转：关闭当前文件并重命名为 名称-日期.时分秒.log，之后的写入创建新文件
+// 当前文件不存在或为空时不轮转，返回空路径
+func (w *DailyRotateWriter) Rotate() (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return "", err
+		}
+		w.file = nil
+	}
+	if w.currentFile == "" {
+		return "", nil
+	}
+	info, err := os.Stat(w.currentFile)
+	if err != nil || info.Size() == 0 {
+		return "", nil
+	}
+
+	base := strings.TrimSuffix(w.currentFile, ".log") + "." + time.Now().Format("150405")
+	rotated := base + ".log"
+	for i := 1; ; i++ {
+		if _, err := os.Stat(rotated); os.IsNotExist(err) {
+			break
+		}
+		rotated = fmt.Sprintf("%s-%d.log", base, i)
+	}
+	if err := os.Rename(w.currentFile, rotated); err != nil {
+		return "", err
+	}
+	return rotated, nil
+}
+
+// Sync 把当前文件的内容刷到磁盘
+func (w *DailyRotateWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file != nil {
+		return w.file.Sync()
+	}
+	return nil
+}
+
+// activeFile 返回正在写入的文件路径
+func (w *DailyRotateWriter) activeFile() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.currentFile
+}
+
 // Close 关闭文件
 func (w *DailyRotateWriter) Close() error {
 	w.mu.Lock()
@@ -165,24 +256,70 @@ type TextFormatter struct {
 func NewLogManagerService(config *Config.LogConfig) *LogManagerService {
 	ctx, cancel := context.WithCancel(context.Background())
 
+	queueSize := config.QueueSize
+	if queueSize <= 0 {
+		queueSize = 1000
+	}
+
 	service := &LogManagerService{
 		config:     config,
 		loggers:    make(map[string]*Logger),
-		asyncQueue: make(chan LogEntry, 1000),
+		asyncQueue: make(chan LogEntry, queueSize),
 		stats: &LogStats{
-			LogsByLevel:  make(map[Config.LogLevel]int64),
-			LogsByLogger: make(map[string]int64),
-			Performance:  make(map[string]float64),
-			LastReset:    time.Now(),
+			LogsByLevel:     make(map[Config.LogLevel]int64),
+			LogsByLogger:    make(map[string]int64),
+			Performance:     make(map[string]float64),
+			LastReset:       time.Now(),
+			DroppedByLogger: make(map[string]int64),
+			SampledByLogger: make(map[string]int64),
+			DroppedByPolicy: make(map[string]int64),
 		},
-		ctx:    ctx,
-		cancel: cancel,
+		ctx:     ctx,
+		cancel:  cancel,
+		sampler: newLogSampler(config.Sampling),
+	}
+	if policies, err := config.ParseLoggerOverflowPolicies(); err == nil {
+		service.overflowPolicies = policies
+	}
+	service.gormLogger = NewGormLogger(service)
+
+	if config.Masking.Enabled {
+		masker, err := NewLogMasker(config.Masking)
+		if err != nil {
+			// 自定义规则有误时退回内置规则，保证脱敏不会因配置错误而失效
+			fmt.Printf("[WARN] 日志脱敏配置错误，仅使用内置规则: %v\n", err)
+			fallback := config.Masking
+			fallback.Patterns = nil
+			masker, _ = NewLogMasker(fallback)
+		}
+		service.masker = masker
 	}
 
 	service.initLoggers()
-	go service.processAsyncLogs()
+	if config.Shipping.Enabled {
+		service.shipper = NewLogShipper(&config.Shipping, config.BasePath)
+		service.shipper.Start()
+	}
+	if config.ErrorReporting.Enabled {
+		environment := ""
+		if globalConfig := Config.GetConfig(); globalConfig != nil {
+			environment = globalConfig.Server.Environment
+		}
+		reporter, err := NewErrorReporter(&config.ErrorReporting, config.Masking, environment)
+		if err != nil {
+			fmt.Printf("[WARN] 错误上报初始化失败，不上报错误: %v\n", err)
+		} else {
+			service.reporter = reporter
+			service.reporter.Start()
+		}
+	}
+	for i := 0; i < service.workerCount(); i++ {
+		service.workers.Add(1)
+		go service.processAsyncLogs()
+	}
 	go service.collectStats()
 	go service.monitorPerformance()
+	go service.maintainLogFiles()
 
 	return service
 }
@@ -270,8 +407,9 @@ func (s *LogManagerService) createLogger(name string, config interface{}, path s
 		basePath:    logPath,
 		baseName:    name,
 		currentFile: logFile,
-		config:      s.config.Rotation,
+		config:      s.rotationFor(name),
 	}
+	writer.onRotate = func() { s.maintainLogger(s.ctx, name) }
 
 	var formatter LogFormatter
 	switch s.getLogFormat(config) {
@@ -287,6 +425,10 @@ func (s *LogManagerService) createLogger(name string, config interface{}, path s
 			ShowLevel:     true,
 			Colorize:      s.config.Format == "color",
 		}
+	case Config.LogFormatCombined:
+		formatter = &CombinedFormatter{}
+	case Config.LogFormatCommon:
+		formatter = &CombinedFormatter{Common: true}
 	default:
 		formatter = &JSONFormatter{}
 	}
@@ -376,12 +518,12 @@ func (s *LogManagerService) isLoggerEnabled(config interface{}) bool {
 // 1. 异步记录日志，不阻塞调用者
 // 2. 自动收集调用者信息（文件名、行号等）
 // 3. 错误级别日志自动包含堆栈跟踪
-// 4. 队列满时降级为同步处理，确保日志不丢失
+// 4. 队列满时按日志记录器的背压策略处理（sync、block、drop、drop_oldest、sample）
 //
 // 异步处理机制：
-// - 使用带缓冲的channel（asyncQueue）实现异步日志
+// - 使用带缓冲的channel（asyncQueue）实现异步日志，由写入协程池批量写入
 // - 默认容量1000，可以缓冲大量日志
-// - 如果队列满，降级为同步处理，确保日志不丢失
+// - 如果队列满，默认的 sync 策略降级为同步处理，确保日志不丢失
 //
 // 调用者信息：
 // - 自动获取调用Log()函数的文件名和行号
@@ -395,12 +537,11 @@ func (s *LogManagerService) isLoggerEnabled(config interface{}) bool {
 //
 // 性能优化：
 // - 异步处理不阻塞业务逻辑
-// - 队列满时降级为同步，避免日志丢失
-// - 批量写入可以提高I/O效率
+// - 写入协程把队列中已有的日志合并为一批写入，提高I/O效率
 //
 // 注意事项：
 // - 如果服务已关闭（s.closed），直接返回
-// - 队列满时会阻塞，但可以确保日志不丢失
+// - sync 和 block 策略在队列满时会阻塞调用者，其他策略会丢弃日志并计数
 // - 堆栈跟踪可能很长，需要合理存储
 func (s *LogManagerService) Log(loggerName string, level Config.LogLevel, message string, fields map[string]interface{}) {
 	// 如果服务已关闭，不再记录日志
@@ -408,6 +549,15 @@ func (s *LogManagerService) Log(loggerName string, level Config.LogLevel, messag
 		return
 	}
 
+	// 采样：debug/info 等低级别日志按采样率只保留一部分
+	if !s.sampler.Keep(loggerName, level) {
+		s.countSkipped(loggerName)
+		return
+	}
+
+	// 附加固定字段，调用方传入的同名字段优先
+	fields = s.withStaticFields(fields)
+
 	// 获取调用者信息（文件名、行号等）
 	// 用于定位日志来源，便于问题排查
 	caller := s.getCallerInfo()
@@ -424,24 +574,202 @@ func (s *LogManagerService) Log(loggerName string, level Config.LogLevel, messag
 
 	// 错误级别日志自动包含堆栈跟踪
 	// 堆栈跟踪帮助定位错误发生的位置
-	if level >= Config.LogLevelError && s.shouldIncludeStack(loggerName) {
+	if level.Severity() >= Config.LogLevelError.Severity() && s.shouldIncludeStack(loggerName) {
 		entry.Stack = s.getStackTrace()
 	}
 
-	// 尝试异步写入日志队列
-	// 使用select实现非阻塞发送
+	// 写入日志队列，队列满时按日志记录器的背压策略处理
+	// 统计信息在写入协程写入后更新
+	s.enqueue(entry)
+}
+
+// SetStaticFields 设置附加到每条日志的固定字段
+// 用于记录Pod、命名空间、节点等部署信息，便于集中日志按实例检索
+func (s *LogManagerService) SetStaticFields(fields map[string]interface{}) {
+	static := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		static[key] = value
+	}
+	s.mu.Lock()
+	s.staticFields = static
+	s.mu.Unlock()
+}
+
+// withStaticFields 合并固定字段，返回新的字段表，不修改调用方传入的map
+func (s *LogManagerService) withStaticFields(fields map[string]interface{}) map[string]interface{} {
+	s.mu.RLock()
+	static := s.staticFields
+	s.mu.RUnlock()
+	if len(static) == 0 {
+		return fields
+	}
+
+	merged := make(map[string]interface{}, len(static)+len(fields))
+	for key, value := range static {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return merged
+}
+
+// countSkipped 记录被采样过滤的日志
+func (s *LogManagerService) countSkipped(loggerName string) {
+	s.stats.mu.Lock()
+	defer s.stats.mu.Unlock()
+	s.stats.Sampled++
+	s.stats.SampledByLogger[loggerName]++
+}
+
+const (
+	// defaultLogWorkers 默认的写入协程数
+	defaultLogWorkers = 2
+	// defaultLogBatchSize 写入协程每批最多写入的日志数
+	defaultLogBatchSize = 100
+)
+
+// workerCount 写入协程数，未配置时使用默认值
+func (s *LogManagerService) workerCount() int {
+	if s.config.Workers > 0 {
+		return s.config.Workers
+	}
+	return defaultLogWorkers
+}
+
+// overflowPolicy 返回日志记录器的队列溢出处理方式
+// 优先使用按日志记录器的配置，其次是全局配置，默认同步写入
+func (s *LogManagerService) overflowPolicy(loggerName string) string {
+	if policy, ok := s.overflowPolicies[loggerName]; ok {
+		return policy
+	}
+	if s.config.OverflowPolicy != "" {
+		return s.config.OverflowPolicy
+	}
+	return Config.LogOverflowSync
+}
+
+// enqueue 把日志写入队列，队列满时按背压策略处理
+//
+// 功能说明：
+// 1. 队列有空间时直接入队，不阻塞调用者
+// 2. 队列满时按日志记录器的溢出处理方式处理：
+//   - sync：同步写入，不丢日志但阻塞调用者（默认）
+//   - block：阻塞等待队列空间，超过 LOG_OVERFLOW_BLOCK_TIMEOUT 后丢弃
+//   - drop：丢弃新日志
+//   - drop_oldest：丢弃队列中最旧的日志，为新日志腾出空间
+//   - sample：每 LOG_OVERFLOW_SAMPLE_RATE 条保留1条（按 block 方式等待），其余丢弃
+//
+// 注意事项：
+// - 错误及以上级别的日志不会被丢弃，丢弃时改为同步写入
+// - 丢弃的日志计入 Dropped、DroppedByLogger 和 DroppedByPolicy
+func (s *LogManagerService) enqueue(entry LogEntry) {
+	if s.offer(entry) {
+		return
+	}
+
+	s.stats.mu.Lock()
+	s.stats.Overflows++
+	s.stats.mu.Unlock()
+
+	policy := s.overflowPolicy(entry.Logger)
+	switch policy {
+	case Config.LogOverflowBlock:
+		if s.enqueueWait(entry) {
+			return
+		}
+	case Config.LogOverflowDropOldest:
+		select {
+		case oldest := <-s.asyncQueue:
+			atomic.AddInt64(&s.pending, -1)
+			s.discard(oldest, policy)
+		default:
+		}
+		if s.offer(entry) {
+			return
+		}
+	case Config.LogOverflowSample:
+		rate := uint64(s.config.OverflowSampleRate)
+		if rate <= 1 || atomic.AddUint64(&s.overflowSampled, 1)%rate == 1 {
+			if s.enqueueWait(entry) {
+				return
+			}
+		}
+	case Config.LogOverflowDrop:
+	default:
+		s.writeOverflowSync(entry)
+		return
+	}
+	s.discard(entry, policy)
+}
+
+// offer 非阻塞地把日志写入队列，队列已满时返回 false
+func (s *LogManagerService) offer(entry LogEntry) bool {
+	atomic.AddInt64(&s.pending, 1)
 	select {
 	case s.asyncQueue <- entry:
-		// 成功发送到队列，异步处理
-		// 统计信息会在processAsyncLogs中更新
-		// 这种方式不阻塞调用者，性能最好
+		return true
 	default:
-		// 队列满了，降级为同步处理
-		// 这确保日志不会丢失，但会阻塞调用者
-		// 同步写入并更新统计信息
-		s.writeLogSync(entry)
-		s.updateStats(entry)
+		atomic.AddInt64(&s.pending, -1)
+		return false
+	}
+}
+
+// enqueueWait 阻塞等待队列空间，超时或服务关闭时返回 false
+func (s *LogManagerService) enqueueWait(entry LogEntry) bool {
+	s.stats.mu.Lock()
+	s.stats.Blocked++
+	s.stats.mu.Unlock()
+
+	timeout := s.config.OverflowBlockTimeout
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	atomic.AddInt64(&s.pending, 1)
+	select {
+	case s.asyncQueue <- entry:
+		return true
+	case <-timer.C:
+	case <-s.ctx.Done():
+	}
+	atomic.AddInt64(&s.pending, -1)
+	return false
+}
+
+// discard 丢弃日志并计数，错误及以上级别的日志改为同步写入
+func (s *LogManagerService) discard(entry LogEntry, policy string) {
+	if entry.Level.Severity() >= Config.LogLevelError.Severity() {
+		s.writeOverflowSync(entry)
+		return
 	}
+	s.stats.mu.Lock()
+	defer s.stats.mu.Unlock()
+	s.stats.Dropped++
+	s.stats.DroppedByLogger[entry.Logger]++
+	s.stats.DroppedByPolicy[policy]++
+}
+
+// writeOverflowSync 队列满时同步写入
+func (s *LogManagerService) writeOverflowSync(entry LogEntry) {
+	s.writeLogSync(entry)
+	s.stats.mu.Lock()
+	s.stats.SyncWrites++
+	s.stats.mu.Unlock()
+}
+
+// fillBatch 非阻塞地取出队列中已有的日志追加到批次，直到批次达到 size 条或队列为空
+func (s *LogManagerService) fillBatch(batch []LogEntry, size int) []LogEntry {
+	for len(batch) < size {
+		select {
+		case entry := <-s.asyncQueue:
+			batch = append(batch, entry)
+		default:
+			return batch
+		}
+	}
+	return batch
 }
 
 // LogWithContext 记录带上下文的日志
@@ -450,9 +778,7 @@ func (s *LogManagerService) LogWithContext(ctx context.Context, loggerName strin
 		fields = make(map[string]interface{})
 	}
 
-	if requestID := ctx.Value("request_id"); requestID != nil {
-		fields["request_id"] = requestID
-	}
+	addRequestIDFields(ctx, fields)
 	if userID := ctx.Value("user_id"); userID != nil {
 		fields["user_id"] = userID
 	}
@@ -484,9 +810,7 @@ func (s *LogManagerService) LogRequest(ctx context.Context, method, path string,
 	fields["duration"] = duration.String()
 	fields["duration_ms"] = duration.Milliseconds()
 
-	if requestID := ctx.Value("request_id"); requestID != nil {
-		fields["request_id"] = requestID
-	}
+	addRequestIDFields(ctx, fields)
 	if userID := ctx.Value("user_id"); userID != nil {
 		fields["user_id"] = userID
 	}
@@ -504,6 +828,9 @@ func (s *LogManagerService) LogSQL(ctx context.Context, sql string, duration tim
 	if !s.config.SQLLog.Enabled {
 		return
 	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
 
 	if fields == nil {
 		fields = make(map[string]interface{})
@@ -530,10 +857,15 @@ func (s *LogManagerService) LogSQL(ctx context.Context, sql string, duration tim
 		level = Config.LogLevelWarning
 	}
 
-	s.Log("sql", level, "SQL执行", fields)
+	// 附带请求ID、关联ID和用户ID，便于按请求查找SQL
+	s.LogWithContext(ctx, "sql", level, "SQL执行", fields)
 }
 
+// LogError 记录错误日志，启用错误上报时同时上报到 Sentry/Bugsnag（不受错误日志开关影响）
 func (s *LogManagerService) LogError(ctx context.Context, error error, message string, fields map[string]interface{}) {
+	if s.reporter != nil && error != nil {
+		s.reporter.CaptureError(ctx, error, message, fields)
+	}
 	if !s.config.ErrorLog.Enabled {
 		return
 	}
@@ -545,9 +877,7 @@ func (s *LogManagerService) LogError(ctx context.Context, error error, message s
 	fields["error"] = error.Error()
 	fields["error_type"] = fmt.Sprintf("%T", error)
 
-	if requestID := ctx.Value("request_id"); requestID != nil {
-		fields["request_id"] = requestID
-	}
+	addRequestIDFields(ctx, fields)
 	if userID := ctx.Value("user_id"); userID != nil {
 		fields["user_id"] = userID
 	}
@@ -556,6 +886,7 @@ func (s *LogManagerService) LogError(ctx context.Context, error error, message s
 }
 
 func (s *LogManagerService) LogAudit(ctx context.Context, action string, resource string, resourceID interface{}, fields map[string]interface{}) {
+	s.addBreadcrumb(ctx, "audit", Config.LogLevelInfo, fmt.Sprintf("%s %s", action, resource), map[string]interface{}{"resource_id": resourceID})
 	if !s.config.AuditLog.Enabled {
 		return
 	}
@@ -568,9 +899,7 @@ func (s *LogManagerService) LogAudit(ctx context.Context, action string, resourc
 	fields["resource"] = resource
 	fields["resource_id"] = resourceID
 
-	if requestID := ctx.Value("request_id"); requestID != nil {
-		fields["request_id"] = requestID
-	}
+	addRequestIDFields(ctx, fields)
 	if userID := ctx.Value("user_id"); userID != nil {
 		fields["user_id"] = userID
 	}
@@ -582,6 +911,7 @@ func (s *LogManagerService) LogAudit(ctx context.Context, action string, resourc
 }
 
 func (s *LogManagerService) LogSecurity(ctx context.Context, event string, level Config.LogLevel, fields map[string]interface{}) {
+	s.addBreadcrumb(ctx, "security", level, event, fields)
 	if !s.config.SecurityLog.Enabled {
 		return
 	}
@@ -593,9 +923,7 @@ func (s *LogManagerService) LogSecurity(ctx context.Context, event string, level
 	fields["security_event"] = event
 	fields["security_level"] = string(level)
 
-	if requestID := ctx.Value("request_id"); requestID != nil {
-		fields["request_id"] = requestID
-	}
+	addRequestIDFields(ctx, fields)
 	if userID := ctx.Value("user_id"); userID != nil {
 		fields["user_id"] = userID
 	}
@@ -607,6 +935,7 @@ func (s *LogManagerService) LogSecurity(ctx context.Context, event string, level
 }
 
 func (s *LogManagerService) LogBusiness(ctx context.Context, module string, action string, message string, fields map[string]interface{}) {
+	s.addBreadcrumb(ctx, "business."+module, Config.LogLevelInfo, message, map[string]interface{}{"action": action})
 	if !s.config.BusinessLog.Enabled {
 		return
 	}
@@ -618,9 +947,7 @@ func (s *LogManagerService) LogBusiness(ctx context.Context, module string, acti
 	fields["module"] = module
 	fields["action"] = action
 
-	if requestID := ctx.Value("request_id"); requestID != nil {
-		fields["request_id"] = requestID
-	}
+	addRequestIDFields(ctx, fields)
 	if userID := ctx.Value("user_id"); userID != nil {
 		fields["user_id"] = userID
 	}
@@ -628,6 +955,37 @@ func (s *LogManagerService) LogBusiness(ctx context.Context, module string, acti
 	s.Log("business", Config.LogLevelInfo, message, fields)
 }
 
+// addBreadcrumb 在请求的错误上下文中添加面包屑，错误上报时随事件发送
+func (s *LogManagerService) addBreadcrumb(ctx context.Context, category string, level Config.LogLevel, message string, fields map[string]interface{}) {
+	if s.reporter == nil || ctx == nil {
+		return
+	}
+	scope := ErrorScopeFromContext(ctx)
+	if scope == nil {
+		return
+	}
+	data := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		data[key] = value
+	}
+	scope.AddBreadcrumb(Breadcrumb{Category: category, Message: message, Level: string(level), Data: data})
+}
+
+// addRequestIDFields 添加上下文中的请求ID和关联ID，用于跨系统关联日志
+func addRequestIDFields(ctx context.Context, fields map[string]interface{}) {
+	if requestID := Utils.RequestIDFromContext(ctx); requestID != "" {
+		fields["request_id"] = requestID
+	}
+	if correlationID := Utils.CorrelationIDFromContext(ctx); correlationID != "" {
+		fields["correlation_id"] = correlationID
+	}
+}
+
+// ErrorReporter 获取错误上报服务，未启用时返回nil
+func (s *LogManagerService) ErrorReporter() *ErrorReporter {
+	return s.reporter
+}
+
 func (s *LogManagerService) LogAccess(ctx context.Context, method, path string, statusCode int, userAgent string, fields map[string]interface{}) {
 	if !s.config.AccessLog.Enabled {
 		return
@@ -642,9 +1000,7 @@ func (s *LogManagerService) LogAccess(ctx context.Context, method, path string,
 	fields["status_code"] = statusCode
 	fields["user_agent"] = userAgent
 
-	if requestID := ctx.Value("request_id"); requestID != nil {
-		fields["request_id"] = requestID
-	}
+	addRequestIDFields(ctx, fields)
 	if userID := ctx.Value("user_id"); userID != nil {
 		fields["user_id"] = userID
 	}
@@ -709,10 +1065,10 @@ func (s *LogManagerService) shouldIncludeStack(loggerName string) bool {
 	}
 }
 
-// writeLogSync 同步写入日志
+// writeLogSync 同步写入单条日志
 //
 // 功能说明：
-// 1. 同步写入日志到文件或输出流
+// 1. 同步写入日志到文件或输出流，写入协程按批调用 writeBatch
 // 2. 检查日志记录器是否存在和启用
 // 3. 检查日志级别是否满足要求
 // 4. 格式化日志条目并写入
@@ -743,26 +1099,97 @@ func (s *LogManagerService) shouldIncludeStack(loggerName string) bool {
 // - 写入操作可能阻塞，但通常很快
 // - 延迟数据使用slice存储，需要定期清理
 func (s *LogManagerService) writeLogSync(entry LogEntry) {
+	s.writeBatch([]LogEntry{entry})
+}
+
+// writeBatch 写入一批日志，同一日志记录器的日志合并为一次写入
+// 批内同一日志记录器的日志保持入队顺序；写入完成后再更新统计，统计可见时日志已写入文件
+func (s *LogManagerService) writeBatch(entries []LogEntry) {
+	type pendingWrite struct {
+		logger *Logger
+		data   []byte
+	}
+	writes := make([]*pendingWrite, 0, 1)
+	byLogger := make(map[string]*pendingWrite, 1)
+	for _, entry := range entries {
+		logger, data := s.formatEntry(entry)
+		if logger == nil {
+			continue
+		}
+		pending, exists := byLogger[entry.Logger]
+		if !exists {
+			pending = &pendingWrite{logger: logger}
+			byLogger[entry.Logger] = pending
+			writes = append(writes, pending)
+		}
+		// 添加换行符确保每条日志占一行，便于日志解析和查看
+		pending.data = append(append(pending.data, data...), '\n')
+	}
+
+	for _, pending := range writes {
+		// 记录写入开始时间，用于计算延迟
+		start := time.Now()
+		if _, err := pending.logger.writer.Write(pending.data); err != nil {
+			// 写入失败时记录错误但不中断流程
+			fmt.Printf("日志写入失败: %v\n", err)
+			continue
+		}
+
+		// 计算写入延迟并更新统计信息
+		latency := time.Since(start).Seconds()
+		pending.logger.stats.mu.Lock()
+		// 记录延迟时间（用于性能分析）
+		pending.logger.stats.WriteLatency = append(pending.logger.stats.WriteLatency, latency)
+		// 只保留最近100次的延迟数据，避免内存无限增长
+		if len(pending.logger.stats.WriteLatency) > 100 {
+			pending.logger.stats.WriteLatency = pending.logger.stats.WriteLatency[1:]
+		}
+		pending.logger.stats.mu.Unlock()
+	}
+
+	for _, entry := range entries {
+		s.updateStats(entry)
+	}
+}
+
+// formatEntry 按日志记录器的级别过滤、脱敏、投递并格式化日志条目
+// 日志记录器不存在、未启用、级别不满足或格式化失败时返回 nil
+func (s *LogManagerService) formatEntry(entry LogEntry) (*Logger, []byte) {
 	// 获取日志记录器（使用读锁，允许多个goroutine同时读取）
 	s.mu.RLock()
 	logger, exists := s.loggers[entry.Logger]
+	var level Config.LogLevel
+	if exists {
+		// 级别可以在运行时调整，需要在锁内读取
+		level = logger.level
+	}
 	s.mu.RUnlock()
 
 	// 检查日志记录器是否存在和启用
 	if !exists {
 		fmt.Printf("[DEBUG] 日志记录器不存在: %s\n", entry.Logger)
-		return
+		return nil, nil
 	}
 	if !logger.enabled {
 		fmt.Printf("[DEBUG] 日志记录器未启用: %s\n", entry.Logger)
-		return
+		return nil, nil
 	}
 
 	// 检查日志级别：只写入级别大于等于配置级别的日志
 	// 例如：配置为INFO级别，则DEBUG日志不写入
-	// LogLevel是枚举类型，值越小级别越高（DEBUG=0, INFO=1, ...）
-	if entry.Level < logger.level {
-		return
+	// LogLevel是字符串类型，按严重程度比较（DEBUG=0, INFO=1, ...）
+	if entry.Level.Severity() < level.Severity() {
+		return nil, nil
+	}
+
+	// 脱敏在格式化和投递之前进行，本地文件和集中式日志都不会包含敏感信息
+	if s.masker != nil {
+		entry = s.masker.MaskEntry(entry)
+	}
+
+	// 投递到集中式日志系统（不阻塞，本地写入失败也不影响投递）
+	if s.shipper != nil {
+		s.shipper.Ship(entry)
 	}
 
 	// 格式化日志条目（JSON或文本格式）
@@ -770,78 +1197,49 @@ func (s *LogManagerService) writeLogSync(entry LogEntry) {
 	if err != nil {
 		// 格式化失败时记录错误但不中断流程
 		fmt.Printf("日志格式化失败: %v\n", err)
-		return
-	}
-
-	// 记录写入开始时间，用于计算延迟
-	start := time.Now()
-	
-	// 添加换行符确保每条日志占一行
-	// 这样便于日志解析和查看
-	logData := append(data, '\n')
-	
-	// 写入日志数据
-	_, err = logger.writer.Write(logData)
-	if err != nil {
-		// 写入失败时记录错误但不中断流程
-		fmt.Printf("日志写入失败: %v\n", err)
-		return
+		return nil, nil
 	}
-
-	// 计算写入延迟并更新统计信息
-	latency := time.Since(start).Seconds()
-	logger.stats.mu.Lock()
-	// 记录延迟时间（用于性能分析）
-	logger.stats.WriteLatency = append(logger.stats.WriteLatency, latency)
-	// 只保留最近100次的延迟数据，避免内存无限增长
-	if len(logger.stats.WriteLatency) > 100 {
-		logger.stats.WriteLatency = logger.stats.WriteLatency[1:]
-	}
-	logger.stats.mu.Unlock()
+	return logger, data
 }
 
-// processAsyncLogs 处理异步日志
+// processAsyncLogs 写入协程，处理异步日志队列
 //
 // 功能说明：
-// 1. 在独立的goroutine中运行，持续处理日志队列
-// 2. 从异步队列中取出日志条目并写入
-// 3. 更新日志统计信息
-// 4. 支持优雅停止，通过context控制
-//
-// 实现原理：
-// - 使用无限循环持续监听日志队列
-// - 使用select同时监听队列和context取消信号
-// - 当收到日志条目时，同步写入并更新统计
-//
-// 优雅停止：
-// - 当context被取消时（服务关闭），立即退出
-// - 确保所有已入队的日志都被处理
-// - 避免日志丢失
-//
-// 性能考虑：
-// - 异步处理不阻塞日志调用者
-// - 批量处理可以提高I/O效率（可扩展）
-// - 如果队列满，调用者会降级为同步处理
+// 1. 由 LOG_WORKERS 个协程并发运行，共同消费日志队列
+// 2. 取到一条日志后继续非阻塞地取出队列中已有的日志，最多 LOG_BATCH_SIZE 条组成一批
+// 3. 同一批中同一日志文件的日志合并为一次写入，突发流量时减少系统调用
+// 4. 服务关闭时写完队列中剩余的日志再退出
 //
 // 注意事项：
-// - 这个goroutine在服务启动时创建，服务关闭时退出
+// - 多个写入协程时，不同批次之间的写入顺序可能与时间戳略有差异；需要严格有序时设置 LOG_WORKERS=1
 // - 如果写入失败，只记录错误，不中断处理流程
-// - 统计信息更新需要加锁保护
 func (s *LogManagerService) processAsyncLogs() {
-	// 无限循环，持续处理日志队列
+	defer s.workers.Done()
+
+	batchSize := s.config.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultLogBatchSize
+	}
+	batch := make([]LogEntry, 0, batchSize)
 	for {
 		select {
 		case entry := <-s.asyncQueue:
-			// 从队列中取出日志条目
-			// 同步写入日志文件（确保顺序和一致性）
-			s.writeLogSync(entry)
-			// 更新统计信息（总日志数、按级别统计等）
-			s.updateStats(entry)
+			batch = s.fillBatch(append(batch[:0], entry), batchSize)
+			s.writeBatch(batch)
+			atomic.AddInt64(&s.pending, -int64(len(batch)))
+			s.stats.mu.Lock()
+			s.stats.Batches++
+			s.stats.mu.Unlock()
 		case <-s.ctx.Done():
-			// 收到停止信号（服务关闭）
-			// 立即退出goroutine，实现优雅停止
-			// 注意：队列中可能还有未处理的日志，但服务关闭时通常可以接受
-			return
+			// 收到停止信号（服务关闭），写完队列中剩余的日志
+			for {
+				batch = s.fillBatch(batch[:0], batchSize)
+				if len(batch) == 0 {
+					return
+				}
+				s.writeBatch(batch)
+				atomic.AddInt64(&s.pending, -int64(len(batch)))
+			}
 		}
 	}
 }
@@ -924,7 +1322,7 @@ func (s *LogManagerService) updateStats(entry LogEntry) {
 		logger.stats.TotalLogs++              // 记录器的总日志数
 		logger.stats.LastLog = entry.Timestamp // 最后日志时间
 		// 如果是错误级别（ERROR或FATAL），增加错误计数
-		if entry.Level >= Config.LogLevelError {
+		if entry.Level.Severity() >= Config.LogLevelError.Severity() {
 			logger.stats.ErrorCount++
 		}
 		logger.stats.mu.Unlock()
@@ -1055,6 +1453,20 @@ func (s *LogManagerService) GetStats() *LogStats {
 		Errors:       s.stats.Errors,
 		Performance:  make(map[string]float64),
 		LastReset:    s.stats.LastReset,
+
+		Dropped:         s.stats.Dropped,
+		DroppedByLogger: make(map[string]int64),
+		Sampled:         s.stats.Sampled,
+		SampledByLogger: make(map[string]int64),
+		DroppedByPolicy: make(map[string]int64),
+		Overflows:       s.stats.Overflows,
+		Blocked:         s.stats.Blocked,
+		SyncWrites:      s.stats.SyncWrites,
+		Batches:         s.stats.Batches,
+
+		QueueDepth:    len(s.asyncQueue),
+		QueueCapacity: cap(s.asyncQueue),
+		Workers:       s.workerCount(),
 	}
 
 	for level, count := range s.stats.LogsByLevel {
@@ -1066,10 +1478,46 @@ func (s *LogManagerService) GetStats() *LogStats {
 	for metric, value := range s.stats.Performance {
 		stats.Performance[metric] = value
 	}
+	for logger, count := range s.stats.DroppedByLogger {
+		stats.DroppedByLogger[logger] = count
+	}
+	for logger, count := range s.stats.SampledByLogger {
+		stats.SampledByLogger[logger] = count
+	}
+	for policy, count := range s.stats.DroppedByPolicy {
+		stats.DroppedByPolicy[policy] = count
+	}
 
 	return stats
 }
 
+// Collector 监控指标采集器
+// 输出 log_queue_depth、log_queue_capacity、log_queue_usage（0~1）、log_workers，
+// 以及累计的 log_dropped_total、log_overflows_total、log_blocked_total、log_sync_writes_total、log_batches_total
+// 和每个溢出处理方式的 log_dropped_<方式>_total
+func (s *LogManagerService) Collector() MetricCollector {
+	return NewMetricCollector("log_queue", func(ctx context.Context) (map[string]float64, error) {
+		stats := s.GetStats()
+		values := map[string]float64{
+			"log_queue_depth":       float64(stats.QueueDepth),
+			"log_queue_capacity":    float64(stats.QueueCapacity),
+			"log_workers":           float64(stats.Workers),
+			"log_dropped_total":     float64(stats.Dropped),
+			"log_overflows_total":   float64(stats.Overflows),
+			"log_blocked_total":     float64(stats.Blocked),
+			"log_sync_writes_total": float64(stats.SyncWrites),
+			"log_batches_total":     float64(stats.Batches),
+		}
+		if stats.QueueCapacity > 0 {
+			values["log_queue_usage"] = float64(stats.QueueDepth) / float64(stats.QueueCapacity)
+		}
+		for policy, count := range stats.DroppedByPolicy {
+			values["log_dropped_"+policy+"_total"] = float64(count)
+		}
+		return values, nil
+	})
+}
+
 func (s *LogManagerService) GetLoggerStats(loggerName string) *LoggerStats {
 	s.mu.RLock()
 	logger, exists := s.loggers[loggerName]
@@ -1097,7 +1545,24 @@ func (s *LogManagerService) Close() error {
 	s.closed = true
 	s.cancel()
 
-	time.Sleep(100 * time.Millisecond)
+	// 等待写入协程写完队列中剩余的日志
+	done := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		fmt.Printf("[WARN] 日志队列在关闭超时前未写完，剩余 %d 条\n", len(s.asyncQueue))
+	}
+
+	if s.shipper != nil {
+		s.shipper.Close()
+	}
+	if s.reporter != nil {
+		s.reporter.Close(5 * time.Second)
+	}
 
 	s.mu.RLock()
 	for _, logger := range s.loggers {
@@ -1110,6 +1575,80 @@ func (s *LogManagerService) Close() error {
 	return nil
 }
 
+// LoggerLevelInfo 日志记录器的运行时级别信息
+type LoggerLevelInfo struct {
+	Logger     string          `json:"logger"`
+	Level      Config.LogLevel `json:"level"`
+	Enabled    bool            `json:"enabled"`
+	SampleRate int             `json:"sample_rate"`
+
+	OverflowPolicy string `json:"overflow_policy"` // 队列满时的处理方式
+}
+
+// GetLoggerLevels 获取所有日志记录器的当前级别、采样率和队列溢出处理方式
+func (s *LogManagerService) GetLoggerLevels() []LoggerLevelInfo {
+	s.mu.RLock()
+	levels := make([]LoggerLevelInfo, 0, len(s.loggers))
+	for name, logger := range s.loggers {
+		levels = append(levels, LoggerLevelInfo{
+			Logger:         name,
+			Level:          logger.level,
+			Enabled:        logger.enabled,
+			OverflowPolicy: s.overflowPolicy(name),
+		})
+	}
+	s.mu.RUnlock()
+
+	for i := range levels {
+		levels[i].SampleRate = s.sampler.Rate(levels[i].Logger)
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i].Logger < levels[j].Logger })
+	return levels
+}
+
+// SetLoggerLevel 运行时调整日志记录器的级别
+//
+// 调整立即生效，只保存在内存中，重启后恢复为配置文件中的级别。
+func (s *LogManagerService) SetLoggerLevel(loggerName string, level Config.LogLevel) error {
+	if level.Severity() < 0 {
+		return fmt.Errorf("无效的日志级别: %s", level)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	logger, exists := s.loggers[loggerName]
+	if !exists {
+		return fmt.Errorf("日志记录器不存在: %s", loggerName)
+	}
+	logger.level = level
+	return nil
+}
+
+// SetSampleRate 运行时调整日志记录器的采样率（每N条保留1条，1表示不采样）
+func (s *LogManagerService) SetSampleRate(loggerName string, rate int) error {
+	if rate < 1 {
+		return fmt.Errorf("采样率必须大于等于1")
+	}
+
+	s.mu.RLock()
+	_, exists := s.loggers[loggerName]
+	s.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("日志记录器不存在: %s", loggerName)
+	}
+
+	s.sampler.SetRate(loggerName, rate)
+	return nil
+}
+
+// GetShippingStats 获取日志投递统计，未启用投递时返回nil
+func (s *LogManagerService) GetShippingStats() []LogShipperOutputStats {
+	if s.shipper == nil {
+		return nil
+	}
+	return s.shipper.Stats()
+}
+
 // 格式化器实现
 func (f *JSONFormatter) Format(entry LogEntry) ([]byte, error) {
 	if f.PrettyPrint {
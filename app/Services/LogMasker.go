@@ -0,0 +1,261 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// MaskRule 基于正则的脱敏规则
+//
+// Replace 接收匹配到的子串，返回替换后的内容；为nil时整体替换为脱敏文本。
+type MaskRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+	Replace func(match string) string
+}
+
+// LogMasker 日志脱敏器
+// 功能说明：
+// 1. 按字段名脱敏：Fields、Context 中名称匹配敏感字段的值整体替换（递归处理嵌套map、数组和请求头）
+// 2. 按正则脱敏：消息、SQL、堆栈等所有字符串值依次应用脱敏规则
+// 3. 返回脱敏后的副本，不修改调用方传入的 map
+//
+// 内置规则：
+// - authorization: Bearer/Basic 认证凭据
+// - jwt: JWT 令牌
+// - card: 通过 Luhn 校验的银行卡号，保留后4位
+// - key_value: password=xxx、"token": "xxx" 这类键值对中的值（覆盖SQL、查询字符串和JSON文本）
+type LogMasker struct {
+	fields      []string
+	rules       []MaskRule
+	replacement string
+}
+
+// maskKeyValueKeys key_value 规则识别的键名
+const maskKeyValueKeys = `password|passwd|pwd|secret|token|access_token|refresh_token|api_key|apikey|client_secret`
+
+// NewLogMasker 根据配置创建脱敏器
+//
+// 自定义正则无法编译时返回错误。
+func NewLogMasker(config Config.LogMaskingConfig) (*LogMasker, error) {
+	masker := &LogMasker{replacement: config.Replacement}
+	if masker.replacement == "" {
+		masker.replacement = "***MASKED***"
+	}
+
+	for _, field := range config.Fields {
+		if field = normalizeMaskField(field); field != "" {
+			masker.fields = append(masker.fields, field)
+		}
+	}
+
+	for _, name := range config.Rules {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		rule, ok := builtinMaskRule(name, masker.replacement)
+		if !ok {
+			return nil, fmt.Errorf("未知的脱敏规则: %s", name)
+		}
+		masker.rules = append(masker.rules, rule)
+	}
+
+	for i, pattern := range config.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("无效的脱敏正则 %q: %v", pattern, err)
+		}
+		masker.rules = append(masker.rules, MaskRule{Name: fmt.Sprintf("custom_%d", i+1), Pattern: re})
+	}
+
+	return masker, nil
+}
+
+// AddRule 添加脱敏规则
+func (m *LogMasker) AddRule(rule MaskRule) {
+	m.rules = append(m.rules, rule)
+}
+
+// MaskEntry 脱敏日志条目，返回副本
+func (m *LogMasker) MaskEntry(entry LogEntry) LogEntry {
+	entry.Message = m.MaskString(entry.Message)
+	entry.Stack = m.MaskString(entry.Stack)
+	if entry.Fields != nil {
+		entry.Fields = m.MaskFields(entry.Fields)
+	}
+	if entry.Context != nil {
+		entry.Context = m.MaskFields(entry.Context)
+	}
+	return entry
+}
+
+// MaskFields 脱敏字段集合，返回副本
+func (m *LogMasker) MaskFields(fields map[string]interface{}) map[string]interface{} {
+	masked := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		if m.IsSensitiveField(key) {
+			masked[key] = m.replacement
+			continue
+		}
+		masked[key] = m.MaskValue(value)
+	}
+	return masked
+}
+
+// MaskValue 脱敏任意值
+//
+// 支持字符串、字节切片、嵌套map、数组和 http.Header，其他类型原样返回。
+func (m *LogMasker) MaskValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return m.MaskString(v)
+	case []byte:
+		return m.MaskString(string(v))
+	case map[string]interface{}:
+		return m.MaskFields(v)
+	case map[string]string:
+		masked := make(map[string]string, len(v))
+		for key, item := range v {
+			if m.IsSensitiveField(key) {
+				masked[key] = m.replacement
+			} else {
+				masked[key] = m.MaskString(item)
+			}
+		}
+		return masked
+	case http.Header:
+		return m.maskMultiMap(v)
+	case map[string][]string:
+		return m.maskMultiMap(v)
+	case []interface{}:
+		masked := make([]interface{}, len(v))
+		for i, item := range v {
+			masked[i] = m.MaskValue(item)
+		}
+		return masked
+	case []string:
+		masked := make([]string, len(v))
+		for i, item := range v {
+			masked[i] = m.MaskString(item)
+		}
+		return masked
+	case error:
+		return m.MaskString(v.Error())
+	default:
+		return value
+	}
+}
+
+// MaskString 对字符串应用所有正则规则
+func (m *LogMasker) MaskString(value string) string {
+	if value == "" {
+		return value
+	}
+	for _, rule := range m.rules {
+		if rule.Replace != nil {
+			value = rule.Pattern.ReplaceAllStringFunc(value, rule.Replace)
+		} else {
+			value = rule.Pattern.ReplaceAllLiteralString(value, m.replacement)
+		}
+	}
+	return value
+}
+
+// IsSensitiveField 检查字段名是否敏感
+func (m *LogMasker) IsSensitiveField(name string) bool {
+	name = normalizeMaskField(name)
+	for _, field := range m.fields {
+		if strings.Contains(name, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskMultiMap 脱敏请求头这类多值map
+func (m *LogMasker) maskMultiMap(values map[string][]string) map[string][]string {
+	masked := make(map[string][]string, len(values))
+	for key, items := range values {
+		copied := make([]string, len(items))
+		for i, item := range items {
+			if m.IsSensitiveField(key) {
+				copied[i] = m.replacement
+			} else {
+				copied[i] = m.MaskString(item)
+			}
+		}
+		masked[key] = copied
+	}
+	return masked
+}
+
+// normalizeMaskField 字段名转小写并去掉 - 和 _，使 api_key、Api-Key、apiKey 等写法都能匹配
+func normalizeMaskField(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer("-", "", "_", "").Replace(name)
+}
+
+// builtinMaskRule 获取内置脱敏规则
+func builtinMaskRule(name, replacement string) (MaskRule, bool) {
+	switch name {
+	case "authorization":
+		re := regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9\-._~+/]+=*`)
+		return MaskRule{Name: name, Pattern: re, Replace: func(match string) string {
+			return re.ReplaceAllString(match, "$1 "+replacement)
+		}}, true
+	case "jwt":
+		return MaskRule{Name: name, Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)}, true
+	case "card":
+		return MaskRule{Name: name, Pattern: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), Replace: maskCardNumber}, true
+	case "key_value":
+		re := regexp.MustCompile(`(?i)(["']?\b(?:` + maskKeyValueKeys + `)\b["']?\s*[:=]\s*)("[^"]*"|'[^']*'|[^\s&,;"')]+)`)
+		return MaskRule{Name: name, Pattern: re, Replace: func(match string) string {
+			parts := re.FindStringSubmatch(match)
+			value := parts[2]
+			// 保留引号，便于SQL和JSON文本保持可读
+			if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') {
+				return parts[1] + value[:1] + replacement + value[len(value)-1:]
+			}
+			return parts[1] + replacement
+		}}, true
+	}
+	return MaskRule{}, false
+}
+
+// maskCardNumber 脱敏通过 Luhn 校验的卡号，只保留后4位
+//
+// 不通过校验的数字串（订单号、时间戳等）原样保留，减少误伤。
+func maskCardNumber(match string) string {
+	digits := make([]byte, 0, len(match))
+	for i := 0; i < len(match); i++ {
+		if match[i] >= '0' && match[i] <= '9' {
+			digits = append(digits, match[i])
+		}
+	}
+	if len(digits) < 13 || len(digits) > 19 || !luhnValid(digits) {
+		return match
+	}
+	return strings.Repeat("*", len(digits)-4) + string(digits[len(digits)-4:])
+}
+
+// luhnValid Luhn 校验
+func luhnValid(digits []byte) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -429,7 +429,28 @@ func (s *LogMonitorService) checkRule(rule *LogRule) {
 }
 
 // triggerAlert 触发告警
+// 同一指纹已有未解决告警时只累加次数和更新最近触发时间，不创建新告警
 func (s *LogMonitorService) triggerAlert(rule *LogRule) {
+	now := time.Now()
+	fingerprint := AlertFingerprint(rule.ID, "log_count", map[string]string{
+		"logger": rule.Logger,
+		"level":  string(rule.Level),
+	})
+
+	s.alertsMu.Lock()
+	for _, existing := range s.alerts {
+		if existing.Fingerprint == fingerprint && existing.Status != "resolved" {
+			existing.Count++
+			existing.LastSeenAt = now
+			existing.Value = float64(rule.TriggerCount + 1)
+			s.alertsMu.Unlock()
+			rule.LastTrigger = now
+			rule.TriggerCount++
+			return
+		}
+	}
+	s.alertsMu.Unlock()
+
 	// 检查是否在时间窗口内已经触发过
 	if time.Since(rule.LastTrigger) < rule.TimeWindow {
 		return
@@ -437,6 +458,9 @@ func (s *LogMonitorService) triggerAlert(rule *LogRule) {
 
 	// 创建告警
 	alert := &Models.Alert{
+		Fingerprint: fingerprint,
+		Count:       1,
+		LastSeenAt:  now,
 		RuleName:    rule.Name,
 		Type:        "log_monitoring",
 		MetricType:  "log_count",
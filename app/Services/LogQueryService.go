@@ -0,0 +1,465 @@
+package Services
+
+import (
+	"bufio"
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LogQuery 日志查询条件
+type LogQuery struct {
+	Loggers  []string          // 日志记录器，为空表示全部
+	Levels   []Config.LogLevel // 日志级别，为空表示全部
+	MinLevel Config.LogLevel   // 最低级别，与 Levels 同时使用时都需满足
+	From     *time.Time        // 开始时间
+	To       *time.Time        // 结束时间
+	TraceID  string            // 链路追踪ID（精确匹配）
+	Text     string            // 全文关键词（不区分大小写，匹配整行内容）
+	Page     int               // 页码，从1开始
+	PageSize int               // 每页数量
+}
+
+// LogQueryResult 日志查询结果
+type LogQueryResult struct {
+	Entries   []LogEntry `json:"entries"`
+	Total     int        `json:"total"`
+	Page      int        `json:"page"`
+	PageSize  int        `json:"page_size"`
+	Scanned   int        `json:"scanned"`   // 扫描的日志行数
+	Truncated bool       `json:"truncated"` // 达到扫描上限，Total 可能偏小
+}
+
+// LogFileInfo 日志文件信息
+type LogFileInfo struct {
+	Logger     string    `json:"logger"`
+	Name       string    `json:"name"`
+	Date       string    `json:"date"`
+	Size       int64     `json:"size"`
+	ModTime    time.Time `json:"mod_time"`
+	Compressed bool      `json:"compressed"` // 轮转后压缩的 .log.gz 文件
+}
+
+// TailableLoggers 允许实时跟踪的日志记录器
+var TailableLoggers = []string{"error", "security"}
+
+const (
+	logQueryDefaultPageSize = 50
+	logQueryMaxPageSize     = 500
+	logQueryMaxScanLines    = 200000
+	logTailPollInterval     = 500 * time.Millisecond
+)
+
+// LogQueryService 日志查询服务
+// 功能说明：
+// 1. 按日志记录器、级别、时间范围、链路追踪ID和关键词搜索本地日志文件
+// 2. 结果按时间倒序分页返回，按文件名中的日期跳过时间范围外的文件，包括轮转后压缩的文件
+// 3. 实时跟踪（tail -f）日志文件的新增内容，自动处理按日期轮转和文件截断
+//
+// 注意事项：
+// - 只解析JSON格式的日志行，文本格式的行作为消息原文返回，不参与级别和时间过滤
+// - 单次查询最多扫描 logQueryMaxScanLines 行，超出时结果标记为 Truncated
+type LogQueryService struct {
+	config *Config.LogConfig
+}
+
+// NewLogQueryService 创建日志查询服务
+func NewLogQueryService(config *Config.LogConfig) *LogQueryService {
+	if config == nil {
+		config = &Config.LogConfig{}
+		config.SetDefaults()
+	}
+	return &LogQueryService{config: config}
+}
+
+// LoggerPaths 获取已启用的日志记录器及其目录
+func (s *LogQueryService) LoggerPaths() map[string]string {
+	paths := make(map[string]string)
+	add := func(name string, enabled bool, path string) {
+		if enabled && path != "" {
+			paths[name] = filepath.Join(s.config.BasePath, path)
+		}
+	}
+	add("request", s.config.RequestLog.Enabled, s.config.RequestLog.Path)
+	add("sql", s.config.SQLLog.Enabled, s.config.SQLLog.Path)
+	add("error", s.config.ErrorLog.Enabled, s.config.ErrorLog.Path)
+	add("audit", s.config.AuditLog.Enabled, s.config.AuditLog.Path)
+	add("security", s.config.SecurityLog.Enabled, s.config.SecurityLog.Path)
+	add("business", s.config.BusinessLog.Enabled, s.config.BusinessLog.Path)
+	add("access", s.config.AccessLog.Enabled, s.config.AccessLog.Path)
+	return paths
+}
+
+// HasLogger 检查日志记录器是否存在
+func (s *LogQueryService) HasLogger(name string) bool {
+	_, ok := s.LoggerPaths()[name]
+	return ok
+}
+
+// Files 列出日志文件，按日期倒序
+func (s *LogQueryService) Files(logger string) ([]LogFileInfo, error) {
+	paths := s.LoggerPaths()
+	var names []string
+	if logger != "" {
+		if _, ok := paths[logger]; !ok {
+			return nil, fmt.Errorf("日志记录器不存在: %s", logger)
+		}
+		names = []string{logger}
+	} else {
+		for name := range paths {
+			names = append(names, name)
+		}
+	}
+
+	var files []LogFileInfo
+	for _, name := range names {
+		for _, pattern := range []string{name + "-*.log", name + "-*.log.gz"} {
+			matches, err := filepath.Glob(filepath.Join(paths[name], pattern))
+			if err != nil {
+				return nil, err
+			}
+			for _, path := range matches {
+				info, err := os.Stat(path)
+				if err != nil {
+					continue
+				}
+				files = append(files, LogFileInfo{
+					Logger:     name,
+					Name:       filepath.Base(path),
+					Date:       logFileDate(name, filepath.Base(path)),
+					Size:       info.Size(),
+					ModTime:    info.ModTime(),
+					Compressed: strings.HasSuffix(path, ".gz"),
+				})
+			}
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Date != files[j].Date {
+			return files[i].Date > files[j].Date
+		}
+		if files[i].Logger != files[j].Logger {
+			return files[i].Logger < files[j].Logger
+		}
+		return files[i].ModTime.After(files[j].ModTime)
+	})
+	return files, nil
+}
+
+// Search 搜索日志
+func (s *LogQueryService) Search(ctx context.Context, query LogQuery) (*LogQueryResult, error) {
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 {
+		query.PageSize = logQueryDefaultPageSize
+	}
+	if query.PageSize > logQueryMaxPageSize {
+		query.PageSize = logQueryMaxPageSize
+	}
+
+	loggers := query.Loggers
+	if len(loggers) == 0 {
+		for name := range s.LoggerPaths() {
+			loggers = append(loggers, name)
+		}
+	}
+
+	var files []LogFileInfo
+	for _, logger := range loggers {
+		loggerFiles, err := s.Files(logger)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range loggerFiles {
+			if fileInRange(file.Date, query.From, query.To) {
+				files = append(files, file)
+			}
+		}
+	}
+
+	// 同一天的多个日志记录器合并后按时间排序，保证跨记录器的结果整体倒序
+	byDate := make(map[string][]LogFileInfo)
+	var dates []string
+	for _, file := range files {
+		if _, ok := byDate[file.Date]; !ok {
+			dates = append(dates, file.Date)
+		}
+		byDate[file.Date] = append(byDate[file.Date], file)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(dates)))
+
+	result := &LogQueryResult{Entries: []LogEntry{}, Page: query.Page, PageSize: query.PageSize}
+	offset := (query.Page - 1) * query.PageSize
+	paths := s.LoggerPaths()
+	text := strings.ToLower(strings.TrimSpace(query.Text))
+
+	for _, date := range dates {
+		var matched []LogEntry
+		for _, file := range byDate[date] {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			lines, err := readLogLines(filepath.Join(paths[file.Logger], file.Name))
+			if err != nil {
+				return nil, err
+			}
+			for _, line := range lines {
+				if result.Scanned >= logQueryMaxScanLines {
+					result.Truncated = true
+					break
+				}
+				result.Scanned++
+				if text != "" && !strings.Contains(strings.ToLower(string(line)), text) {
+					continue
+				}
+				entry := parseLogLine(file.Logger, line)
+				if matchLogEntry(entry, query) {
+					matched = append(matched, entry)
+				}
+			}
+		}
+
+		sort.SliceStable(matched, func(i, j int) bool {
+			return matched[i].Timestamp.After(matched[j].Timestamp)
+		})
+		for _, entry := range matched {
+			if result.Total >= offset && len(result.Entries) < query.PageSize {
+				result.Entries = append(result.Entries, entry)
+			}
+			result.Total++
+		}
+		if result.Truncated {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// Tail 实时跟踪日志
+//
+// 从当前文件末尾开始，将新增且满足过滤条件的日志写入返回的通道；ctx 取消后通道关闭。
+// 分页和时间范围条件对实时跟踪无效。
+func (s *LogQueryService) Tail(ctx context.Context, logger string, query LogQuery) (<-chan LogEntry, error) {
+	dir, ok := s.LoggerPaths()[logger]
+	if !ok {
+		return nil, fmt.Errorf("日志记录器不存在: %s", logger)
+	}
+	query.From, query.To = nil, nil
+	text := strings.ToLower(strings.TrimSpace(query.Text))
+
+	entries := make(chan LogEntry, 100)
+	go func() {
+		defer close(entries)
+
+		var (
+			path    string
+			offset  int64
+			partial []byte
+		)
+		currentPath := func() string {
+			return filepath.Join(dir, fmt.Sprintf("%s-%s.log", logger, time.Now().Format("2006-01-02")))
+		}
+
+		// 从当前文件末尾开始
+		path = currentPath()
+		if info, err := os.Stat(path); err == nil {
+			offset = info.Size()
+		}
+
+		ticker := time.NewTicker(logTailPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			// 日期轮转后从新文件开头读取
+			if next := currentPath(); next != path {
+				path, offset, partial = next, 0, nil
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			if info.Size() < offset {
+				// 文件被截断，从头读取
+				offset, partial = 0, nil
+			}
+			if info.Size() == offset {
+				continue
+			}
+
+			data, err := readLogRange(path, offset, info.Size())
+			if err != nil {
+				continue
+			}
+			offset += int64(len(data))
+
+			data = append(partial, data...)
+			lastNewline := bytes.LastIndexByte(data, '\n')
+			if lastNewline < 0 {
+				partial = data
+				continue
+			}
+			partial = append([]byte(nil), data[lastNewline+1:]...)
+
+			for _, line := range bytes.Split(data[:lastNewline], []byte{'\n'}) {
+				line = bytes.TrimSpace(line)
+				if len(line) == 0 {
+					continue
+				}
+				if text != "" && !strings.Contains(strings.ToLower(string(line)), text) {
+					continue
+				}
+				entry := parseLogLine(logger, line)
+				if !matchLogEntry(entry, query) {
+					continue
+				}
+				select {
+				case entries <- entry:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return entries, nil
+}
+
+// IsTailable 检查日志记录器是否允许实时跟踪
+func IsTailable(logger string) bool {
+	for _, name := range TailableLoggers {
+		if name == logger {
+			return true
+		}
+	}
+	return false
+}
+
+// logFileDate 从文件名中取出日期，如 request-2025-01-20.log、手动轮转的 request-2025-01-20.153000.log.gz
+func logFileDate(logger, name string) string {
+	date := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, logger+"-"), ".gz"), ".log")
+	if i := strings.IndexByte(date, '.'); i >= 0 {
+		date = date[:i]
+	}
+	return date
+}
+
+// fileInRange 根据文件日期判断是否可能包含时间范围内的日志
+func fileInRange(date string, from, to *time.Time) bool {
+	day, err := time.ParseInLocation("2006-01-02", date, time.Local)
+	if err != nil {
+		// 无法识别日期的文件不跳过
+		return true
+	}
+	if from != nil && day.Add(24*time.Hour).Before(*from) {
+		return false
+	}
+	if to != nil && day.After(*to) {
+		return false
+	}
+	return true
+}
+
+// readLogLines 读取日志文件的全部非空行，.gz 文件解压后读取
+func readLogLines(path string) ([][]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			lines = append(lines, append([]byte(nil), line...))
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// readLogRange 读取文件指定区间的内容
+func readLogRange(path string, start, end int64) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(start, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(io.LimitReader(file, end-start))
+}
+
+// parseLogLine 解析一行日志，非JSON行作为消息原文
+func parseLogLine(logger string, line []byte) LogEntry {
+	var entry LogEntry
+	if len(line) > 0 && line[0] == '{' && json.Unmarshal(line, &entry) == nil {
+		if entry.Logger == "" {
+			entry.Logger = logger
+		}
+		return entry
+	}
+	return LogEntry{Logger: logger, Message: string(line)}
+}
+
+// matchLogEntry 检查日志是否满足过滤条件（关键词由调用方在解析前过滤）
+func matchLogEntry(entry LogEntry, query LogQuery) bool {
+	if len(query.Levels) > 0 {
+		found := false
+		for _, level := range query.Levels {
+			if strings.EqualFold(string(entry.Level), string(level)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if query.MinLevel != "" && entry.Level.Severity() < query.MinLevel.Severity() {
+		return false
+	}
+	if query.TraceID != "" && entry.TraceID != query.TraceID {
+		return false
+	}
+	if query.From != nil && entry.Timestamp.Before(*query.From) {
+		return false
+	}
+	if query.To != nil && entry.Timestamp.After(*query.To) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,348 @@
+package Services
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// LogFileMaintenance 一个日志记录器的轮转、压缩、归档和清理结果
+type LogFileMaintenance struct {
+	Logger     string   `json:"logger"`
+	Rotated    string   `json:"rotated,omitempty"`    // 手动轮转产生的文件
+	Compressed []string `json:"compressed,omitempty"` // 压缩后的文件
+	Archived   []string `json:"archived,omitempty"`   // 上传到归档存储的文件键
+	Deleted    []string `json:"deleted,omitempty"`    // 按保留策略删除的文件
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// logFile 轮转后的日志文件
+type logFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// SetArchiveStore 设置日志归档存储，启用 LOG_ARCHIVE_ENABLED 时压缩后的日志文件上传到该存储
+func (s *LogManagerService) SetArchiveStore(store ArchiveObjectStore) {
+	s.mu.Lock()
+	s.archiveStore = store
+	s.mu.Unlock()
+}
+
+// rotationFor 返回日志记录器的轮转配置
+// 审计日志按 AUDIT_LOG_RETENTION 保留，使用 AUDIT_LOG_COMPRESS，不按文件数量和总大小清理，避免合规要求的日志被提前删除
+func (s *LogManagerService) rotationFor(name string) Config.LogRotation {
+	rotation := s.config.Rotation
+	if name == "audit" {
+		rotation.MaxAge = s.config.AuditLog.Retention
+		rotation.MaxBackups = 0
+		rotation.MaxTotalSize = 0
+		rotation.Compress = s.config.AuditLog.Compress
+		rotation.Archive = rotation.Archive && rotation.Compress
+	}
+	return rotation
+}
+
+// maintainLogFiles 启动时和每隔 LOG_RETENTION_CHECK_INTERVAL 压缩、归档和清理各日志记录器的旧文件
+func (s *LogManagerService) maintainLogFiles() {
+	interval := s.config.Rotation.CheckInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.MaintainLogFiles(s.ctx)
+		select {
+		case <-ticker.C:
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// MaintainLogFiles 立即压缩、归档和清理所有日志记录器的旧文件
+func (s *LogManagerService) MaintainLogFiles(ctx context.Context) []LogFileMaintenance {
+	var results []LogFileMaintenance
+	for _, name := range s.loggerNames() {
+		results = append(results, s.maintainLogger(ctx, name))
+	}
+	return results
+}
+
+// Flush 等待队列中的日志全部写入并刷到磁盘，ctx 超时时返回错误
+func (s *LogManagerService) Flush(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for atomic.LoadInt64(&s.pending) > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("等待日志写入超时，剩余 %d 条: %w", atomic.LoadInt64(&s.pending), ctx.Err())
+		}
+	}
+
+	var errs []string
+	for _, name := range s.loggerNames() {
+		if writer := s.rotateWriter(name); writer != nil {
+			if err := writer.Sync(); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("刷新日志文件失败: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Rotate 手动轮转日志文件
+//
+// 功能说明：
+// 1. 先等待队列中的日志写入（Flush），避免轮转前排队的日志写入新文件
+// 2. 把各日志记录器当前的文件重命名为 名称-日期.时分秒.log，之后的日志写入新文件
+// 3. 立即压缩、归档轮转后的文件，并按保留策略清理
+//
+// loggers 为空时轮转所有日志记录器；当前文件为空的日志记录器不轮转，只执行清理
+func (s *LogManagerService) Rotate(ctx context.Context, loggers []string) ([]LogFileMaintenance, error) {
+	if len(loggers) == 0 {
+		loggers = s.loggerNames()
+	}
+	for _, name := range loggers {
+		if s.rotateWriter(name) == nil {
+			return nil, fmt.Errorf("日志记录器不存在: %s", name)
+		}
+	}
+	if err := s.Flush(ctx); err != nil {
+		return nil, err
+	}
+
+	results := make([]LogFileMaintenance, 0, len(loggers))
+	for _, name := range loggers {
+		s.maintenanceMu.Lock()
+		rotated, err := s.rotateWriter(name).Rotate()
+		s.maintenanceMu.Unlock()
+
+		if err != nil {
+			results = append(results, LogFileMaintenance{Logger: name, Errors: []string{fmt.Sprintf("轮转失败: %v", err)}})
+			continue
+		}
+		result := s.maintainLogger(ctx, name)
+		result.Rotated = rotated
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// loggerNames 返回按名称排序的日志记录器
+func (s *LogManagerService) loggerNames() []string {
+	s.mu.RLock()
+	names := make([]string, 0, len(s.loggers))
+	for name := range s.loggers {
+		names = append(names, name)
+	}
+	s.mu.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// rotateWriter 返回日志记录器的文件写入器，不存在时返回nil
+func (s *LogManagerService) rotateWriter(name string) *DailyRotateWriter {
+	s.mu.RLock()
+	logger, exists := s.loggers[name]
+	s.mu.RUnlock()
+	if !exists {
+		return nil
+	}
+	writer, _ := logger.writer.(*DailyRotateWriter)
+	return writer
+}
+
+// maintainLogger 压缩、归档和清理一个日志记录器的旧文件
+//
+// 功能说明：
+// 1. 压缩除正在写入的文件外所有未压缩的日志文件（名称-日期.log → 名称-日期.log.gz）
+// 2. 启用归档时先上传压缩文件，上传成功后才删除未压缩的文件；上传失败时保留原文件，下次检查时重试
+// 3. 按保留时间、文件数量和总大小删除最旧的文件，正在写入的文件不删除但计入总大小
+//
+// 注意事项：
+// - 启用归档但未设置归档存储时不压缩，避免未归档的文件被压缩后不再上传
+// - 同一时间只有一个清理任务执行
+func (s *LogManagerService) maintainLogger(ctx context.Context, name string) LogFileMaintenance {
+	result := LogFileMaintenance{Logger: name}
+	writer := s.rotateWriter(name)
+	if writer == nil {
+		return result
+	}
+
+	s.maintenanceMu.Lock()
+	defer s.maintenanceMu.Unlock()
+
+	rotation := s.rotationFor(name)
+	s.mu.RLock()
+	store := s.archiveStore
+	s.mu.RUnlock()
+
+	active := writer.activeFile()
+	files, err := listRotatedLogFiles(writer.basePath, writer.baseName, active)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+
+	if rotation.Compress && (!rotation.Archive || store != nil) {
+		for i, file := range files {
+			if strings.HasSuffix(file.path, ".gz") {
+				continue
+			}
+			compressed, key, err := compressLogFile(ctx, file.path, name, rotation, store)
+			if err != nil {
+				result.Errors = append(result.Errors, err.Error())
+				continue
+			}
+			result.Compressed = append(result.Compressed, filepath.Base(compressed))
+			if key != "" {
+				result.Archived = append(result.Archived, key)
+			}
+			if info, err := os.Stat(compressed); err == nil {
+				files[i] = logFile{path: compressed, size: info.Size(), modTime: file.modTime}
+			}
+		}
+	}
+
+	for _, path := range retentionExpired(files, active, rotation, time.Now()) {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			result.Errors = append(result.Errors, fmt.Sprintf("删除 %s 失败: %v", filepath.Base(path), err))
+			continue
+		}
+		result.Deleted = append(result.Deleted, filepath.Base(path))
+	}
+
+	for _, message := range result.Errors {
+		fmt.Printf("[WARN] 日志文件清理失败 (%s): %s\n", name, message)
+	}
+	return result
+}
+
+// listRotatedLogFiles 列出轮转后的日志文件（名称-*.log 和 名称-*.log.gz），不包括正在写入的文件，按修改时间从新到旧排序
+func listRotatedLogFiles(dir, name, active string) ([]logFile, error) {
+	var files []logFile
+	for _, pattern := range []string{name + "-*.log", name + "-*.log.gz"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range matches {
+			if path == active {
+				continue
+			}
+			info, err := os.Stat(path)
+			if err != nil || info.IsDir() {
+				continue
+			}
+			files = append(files, logFile{path: path, size: info.Size(), modTime: info.ModTime()})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].modTime.Equal(files[j].modTime) {
+			return files[i].modTime.After(files[j].modTime)
+		}
+		return files[i].path > files[j].path
+	})
+	return files, nil
+}
+
+// retentionExpired 返回按保留策略需要删除的文件，files 按修改时间从新到旧排序
+// 总大小包括正在写入的文件，超出时从最旧的文件开始删除
+func retentionExpired(files []logFile, active string, rotation Config.LogRotation, now time.Time) []string {
+	var total int64
+	if info, err := os.Stat(active); err == nil {
+		total = info.Size()
+	}
+	maxTotal := int64(rotation.MaxTotalSize) * 1024 * 1024
+
+	var expired []string
+	for i, file := range files {
+		total += file.size
+		switch {
+		case rotation.MaxAge > 0 && now.Sub(file.modTime) > rotation.MaxAge:
+		case rotation.MaxBackups > 0 && i >= rotation.MaxBackups:
+		case maxTotal > 0 && total > maxTotal:
+		default:
+			continue
+		}
+		expired = append(expired, file.path)
+	}
+	return expired
+}
+
+// compressLogFile 压缩日志文件，启用归档时上传压缩文件
+// 压缩内容先写入临时文件，上传成功后再重命名为 .gz 并删除原文件；返回压缩文件路径和归档文件键
+func compressLogFile(ctx context.Context, path, logger string, rotation Config.LogRotation, store ArchiveObjectStore) (string, string, error) {
+	source, err := os.Open(path)
+	if err != nil {
+		return "", "", fmt.Errorf("打开 %s 失败: %v", filepath.Base(path), err)
+	}
+	defer source.Close()
+	info, err := source.Stat()
+	if err != nil {
+		return "", "", err
+	}
+
+	var buffer bytes.Buffer
+	gz := gzip.NewWriter(&buffer)
+	gz.Name = filepath.Base(path)
+	gz.ModTime = info.ModTime()
+	if _, err := io.Copy(gz, source); err != nil {
+		return "", "", fmt.Errorf("压缩 %s 失败: %v", filepath.Base(path), err)
+	}
+	if err := gz.Close(); err != nil {
+		return "", "", fmt.Errorf("压缩 %s 失败: %v", filepath.Base(path), err)
+	}
+
+	target := path + ".gz"
+	tmp := target + ".tmp"
+	if err := os.WriteFile(tmp, buffer.Bytes(), 0644); err != nil {
+		return "", "", fmt.Errorf("写入 %s 失败: %v", filepath.Base(tmp), err)
+	}
+
+	var key string
+	if rotation.Archive && store != nil {
+		key = logArchiveKey(rotation.ArchivePrefix, logger, filepath.Base(target))
+		if err := store.Put(ctx, key, buffer.Bytes()); err != nil {
+			os.Remove(tmp)
+			return "", "", fmt.Errorf("归档 %s 失败: %v", filepath.Base(target), err)
+		}
+	}
+
+	if err := os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return "", "", fmt.Errorf("重命名 %s 失败: %v", filepath.Base(tmp), err)
+	}
+	// 保留原文件的修改时间，保留策略按最后写入时间计算
+	os.Chtimes(target, info.ModTime(), info.ModTime())
+	if err := os.Remove(path); err != nil {
+		return "", "", fmt.Errorf("删除 %s 失败: %v", filepath.Base(path), err)
+	}
+	return target, key, nil
+}
+
+// logArchiveKey 日志归档文件键：前缀/日志记录器/文件名
+func logArchiveKey(prefix, logger, name string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return logger + "/" + name
+	}
+	return prefix + "/" + logger + "/" + name
+}
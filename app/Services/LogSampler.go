@@ -0,0 +1,100 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// logSampler 日志采样器
+// 功能说明：
+// 1. 按日志记录器和级别分别计数，每 N 条保留第 1 条（确定性采样，便于估算原始量）
+// 2. 只对配置的级别采样，警告及以上级别始终保留
+// 3. 采样率可以在运行时按日志记录器调整
+type logSampler struct {
+	mu          sync.RWMutex
+	enabled     bool
+	levels      map[Config.LogLevel]bool
+	defaultRate int
+	rates       map[string]int
+	counters    sync.Map // logger + level -> *uint64
+}
+
+// newLogSampler 根据配置创建采样器
+func newLogSampler(config Config.LogSamplingConfig) *logSampler {
+	sampler := &logSampler{
+		enabled:     config.Enabled,
+		levels:      make(map[Config.LogLevel]bool),
+		defaultRate: config.Rate,
+		rates:       make(map[string]int),
+	}
+	if sampler.defaultRate < 1 {
+		sampler.defaultRate = 1
+	}
+	for _, level := range config.Levels {
+		level := Config.LogLevel(strings.ToLower(strings.TrimSpace(level)))
+		// 警告及以上级别不参与采样，避免丢失问题线索
+		if level.Severity() >= 0 && level.Severity() < Config.LogLevelWarning.Severity() {
+			sampler.levels[level] = true
+		}
+	}
+	if rates, err := config.ParseLoggerRates(); err == nil {
+		sampler.rates = rates
+	}
+	return sampler
+}
+
+// Keep 判断日志是否保留
+func (s *logSampler) Keep(logger string, level Config.LogLevel) bool {
+	s.mu.RLock()
+	if !s.enabled || !s.levels[level] {
+		s.mu.RUnlock()
+		return true
+	}
+	rate := s.rateLocked(logger)
+	s.mu.RUnlock()
+
+	if rate <= 1 {
+		return true
+	}
+
+	counter, _ := s.counters.LoadOrStore(logger+"|"+string(level), new(uint64))
+	n := atomic.AddUint64(counter.(*uint64), 1)
+	return (n-1)%uint64(rate) == 0
+}
+
+// Rate 获取日志记录器的采样率（未启用采样时为1）
+func (s *logSampler) Rate(logger string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.enabled {
+		return 1
+	}
+	return s.rateLocked(logger)
+}
+
+// SetRate 设置日志记录器的采样率，设置后自动启用采样
+func (s *logSampler) SetRate(logger string, rate int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rate < 1 {
+		rate = 1
+	}
+	s.rates[logger] = rate
+	if rate > 1 {
+		s.enabled = true
+		if len(s.levels) == 0 {
+			s.levels[Config.LogLevelDebug] = true
+			s.levels[Config.LogLevelInfo] = true
+		}
+	}
+}
+
+// rateLocked 获取采样率（调用方持有锁）
+func (s *logSampler) rateLocked(logger string) int {
+	if rate, ok := s.rates[logger]; ok {
+		return rate
+	}
+	return s.defaultRate
+}
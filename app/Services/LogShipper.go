@@ -0,0 +1,554 @@
+package Services
+
+import (
+	"bufio"
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogOutput 日志投递输出接口
+//
+// Send 返回后不得继续持有 entries（调用方会复用切片）。
+// 返回错误表示整批失败，由投递器负责重试和落盘缓冲。
+type LogOutput interface {
+	Name() string
+	Send(ctx context.Context, entries []LogEntry) error
+	Close() error
+}
+
+// LogShipperOutputStats 单个输出的投递统计
+type LogShipperOutputStats struct {
+	Name        string    `json:"name"`
+	Queued      int       `json:"queued"`       // 当前内存队列中的条数
+	Shipped     int64     `json:"shipped"`      // 投递成功的条数（含磁盘缓冲重放）
+	Failed      int64     `json:"failed"`       // 重试后仍失败的批次数
+	Spilled     int64     `json:"spilled"`      // 写入磁盘缓冲的条数（投递失败或队列已满）
+	Replayed    int64     `json:"replayed"`     // 从磁盘缓冲重放成功的条数
+	Dropped     int64     `json:"dropped"`      // 磁盘缓冲超出上限被丢弃的条数
+	BufferBytes int64     `json:"buffer_bytes"` // 磁盘缓冲当前大小
+	LastError   string    `json:"last_error,omitempty"`
+	LastShipped time.Time `json:"last_shipped,omitempty"`
+}
+
+// LogShipper 日志集中投递器
+// 功能说明：
+// 1. 将日志批量投递到 Elasticsearch、Loki、Syslog 等输出，每个输出可以只接收部分日志记录器
+// 2. 每个输出有独立的有界内存队列和后台协程，按批次大小或刷新间隔投递
+// 3. 队列已满时（背压）日志直接溢出到磁盘缓冲，不阻塞业务请求也不丢弃
+// 4. 投递失败时按指数退避重试，仍失败则写入磁盘缓冲
+// 5. 输出恢复后从磁盘缓冲按写入顺序重放，服务重启后也会继续重放
+// 6. 磁盘缓冲超出上限时丢弃最旧的数据并计入统计
+//
+// 注意事项：
+// - 投递是"至少一次"语义，重试和重放可能产生重复（Elasticsearch 输出按内容生成文档ID去重）
+// - 关闭时会尽力投递队列中剩余的日志，失败的部分落盘，下次启动时重放
+type LogShipper struct {
+	config   *Config.LogShippingConfig
+	basePath string
+	outputs  []*logShipperOutput
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	mu       sync.RWMutex
+	started  bool
+}
+
+// logShipperOutput 单个输出的运行状态
+type logShipperOutput struct {
+	output   LogOutput
+	loggers  map[string]bool
+	minLevel Config.LogLevel
+	queue    chan LogEntry
+	buffer   *logDiskBuffer
+	shipper  *LogShipper
+
+	mu    sync.Mutex
+	stats LogShipperOutputStats
+}
+
+// NewLogShipper 创建日志投递器
+// 功能说明：
+// 1. 根据配置创建已启用的 Elasticsearch、Loki、Syslog 输出
+// 2. 磁盘缓冲目录为 <basePath>/<BufferPath>/<输出名称>
+// 3. 创建后需要调用 Start 启动后台投递
+func NewLogShipper(config *Config.LogShippingConfig, basePath string) *LogShipper {
+	ctx, cancel := context.WithCancel(context.Background())
+	shipper := &LogShipper{
+		config:   config,
+		basePath: basePath,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+
+	if config.Elasticsearch.Enabled {
+		shipper.AddOutput(NewElasticsearchLogOutput(&config.Elasticsearch), config.Elasticsearch.Loggers, config.Elasticsearch.MinLevel)
+	}
+	if config.Loki.Enabled {
+		shipper.AddOutput(NewLokiLogOutput(&config.Loki), config.Loki.Loggers, config.Loki.MinLevel)
+	}
+	if config.Syslog.Enabled {
+		shipper.AddOutput(NewSyslogLogOutput(&config.Syslog), config.Syslog.Loggers, config.Syslog.MinLevel)
+	}
+
+	return shipper
+}
+
+// AddOutput 添加输出（必须在 Start 之前调用）
+//
+// loggers 为空表示接收全部日志记录器，minLevel 为空表示接收全部级别。
+func (s *LogShipper) AddOutput(output LogOutput, loggers []string, minLevel Config.LogLevel) {
+	queueSize := s.config.QueueSize
+	if queueSize <= 0 {
+		queueSize = 10000
+	}
+
+	var loggerSet map[string]bool
+	if len(loggers) > 0 {
+		loggerSet = make(map[string]bool, len(loggers))
+		for _, name := range loggers {
+			if name = strings.TrimSpace(name); name != "" {
+				loggerSet[name] = true
+			}
+		}
+	}
+
+	bufferPath := s.config.BufferPath
+	if bufferPath == "" {
+		bufferPath = "shipping"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outputs = append(s.outputs, &logShipperOutput{
+		output:   output,
+		loggers:  loggerSet,
+		minLevel: minLevel,
+		queue:    make(chan LogEntry, queueSize),
+		buffer:   newLogDiskBuffer(filepath.Join(s.basePath, bufferPath, output.Name()), int64(s.config.MaxBufferSize)*1024*1024),
+		shipper:  s,
+		stats:    LogShipperOutputStats{Name: output.Name()},
+	})
+}
+
+// Start 启动所有输出的后台投递协程
+func (s *LogShipper) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+
+	for _, output := range s.outputs {
+		s.wg.Add(1)
+		go output.run(s.ctx, &s.wg)
+	}
+}
+
+// Ship 投递一条日志
+//
+// 不会阻塞：队列已满时日志写入磁盘缓冲，由后台协程稍后重放。
+func (s *LogShipper) Ship(entry LogEntry) {
+	s.mu.RLock()
+	outputs := s.outputs
+	s.mu.RUnlock()
+
+	for _, output := range outputs {
+		if !output.accepts(entry) {
+			continue
+		}
+		select {
+		case output.queue <- entry:
+		default:
+			output.spill([]LogEntry{entry})
+		}
+	}
+}
+
+// Stats 获取所有输出的投递统计
+func (s *LogShipper) Stats() []LogShipperOutputStats {
+	s.mu.RLock()
+	outputs := s.outputs
+	s.mu.RUnlock()
+
+	stats := make([]LogShipperOutputStats, 0, len(outputs))
+	for _, output := range outputs {
+		output.mu.Lock()
+		stat := output.stats
+		output.mu.Unlock()
+		stat.Queued = len(output.queue)
+		stat.BufferBytes = output.buffer.Size()
+		stats = append(stats, stat)
+	}
+	return stats
+}
+
+// Close 停止投递
+//
+// 等待后台协程投递完队列中剩余的日志（失败的部分写入磁盘缓冲），然后关闭输出。
+func (s *LogShipper) Close() error {
+	s.cancel()
+	s.wg.Wait()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, output := range s.outputs {
+		output.output.Close()
+	}
+	return nil
+}
+
+// accepts 检查输出是否接收该日志
+func (o *logShipperOutput) accepts(entry LogEntry) bool {
+	if o.loggers != nil && !o.loggers[entry.Logger] {
+		return false
+	}
+	if o.minLevel != "" && entry.Level.Severity() < o.minLevel.Severity() {
+		return false
+	}
+	return true
+}
+
+// run 后台投递循环
+func (o *logShipperOutput) run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	config := o.shipper.config
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	interval := config.FlushInterval
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	batch := make([]LogEntry, 0, batchSize)
+	for {
+		select {
+		case entry := <-o.queue:
+			batch = append(batch, entry)
+			if len(batch) >= batchSize {
+				o.flush(batch, false)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				o.flush(batch, false)
+				batch = batch[:0]
+			}
+			o.replay(batchSize)
+		case <-ctx.Done():
+			// 投递队列中剩余的日志，关闭阶段只尝试一次，失败直接落盘
+			for {
+				select {
+				case entry := <-o.queue:
+					batch = append(batch, entry)
+					if len(batch) >= batchSize {
+						o.flush(batch, true)
+						batch = batch[:0]
+					}
+					continue
+				default:
+				}
+				break
+			}
+			if len(batch) > 0 {
+				o.flush(batch, true)
+			}
+			return
+		}
+	}
+}
+
+// flush 投递一批日志，失败时写入磁盘缓冲
+func (o *logShipperOutput) flush(batch []LogEntry, final bool) {
+	if err := o.send(batch, final); err != nil {
+		o.mu.Lock()
+		o.stats.Failed++
+		o.stats.LastError = err.Error()
+		o.mu.Unlock()
+		o.spill(batch)
+		return
+	}
+
+	o.mu.Lock()
+	o.stats.Shipped += int64(len(batch))
+	o.stats.LastShipped = time.Now()
+	o.mu.Unlock()
+}
+
+// send 按指数退避重试投递
+func (o *logShipperOutput) send(batch []LogEntry, final bool) error {
+	config := o.shipper.config
+	retries := config.MaxRetries
+	if final || retries < 0 {
+		retries = 0
+	}
+	backoff := config.RetryBackoff
+	if backoff <= 0 {
+		backoff = 500 * time.Millisecond
+	}
+
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-o.shipper.ctx.Done():
+				return err
+			}
+			backoff *= 2
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err = o.output.Send(ctx, batch)
+		cancel()
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// spill 写入磁盘缓冲
+func (o *logShipperOutput) spill(entries []LogEntry) {
+	dropped, err := o.buffer.Append(entries)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err != nil {
+		// 磁盘也无法写入时只能丢弃
+		o.stats.Dropped += int64(len(entries))
+		o.stats.LastError = err.Error()
+		return
+	}
+	o.stats.Spilled += int64(len(entries))
+	o.stats.Dropped += dropped
+}
+
+// replay 从磁盘缓冲重放最旧的一个分段
+//
+// 分段中部分投递成功时，剩余部分写回分段，下次继续。
+func (o *logShipperOutput) replay(batchSize int) {
+	path, entries, err := o.buffer.Oldest()
+	if err != nil || path == "" {
+		return
+	}
+
+	for len(entries) > 0 {
+		n := batchSize
+		if n > len(entries) {
+			n = len(entries)
+		}
+		if err := o.send(entries[:n], true); err != nil {
+			o.mu.Lock()
+			o.stats.LastError = err.Error()
+			o.mu.Unlock()
+			o.buffer.Rewrite(path, entries)
+			return
+		}
+
+		o.mu.Lock()
+		o.stats.Shipped += int64(n)
+		o.stats.Replayed += int64(n)
+		o.stats.LastShipped = time.Now()
+		o.mu.Unlock()
+		entries = entries[n:]
+	}
+
+	o.buffer.Remove(path)
+}
+
+// logDiskBuffer 磁盘缓冲
+//
+// 以 NDJSON 分段文件保存待投递的日志，文件名为创建时间的纳秒时间戳，按文件名顺序重放。
+// 新数据追加到当前活动分段，活动分段超过 logBufferSegmentSize 或被重放时封存。
+type logDiskBuffer struct {
+	dir      string
+	maxBytes int64
+	mu       sync.Mutex
+	active   string
+}
+
+// logBufferSegmentSize 单个分段的最大大小
+const logBufferSegmentSize = 8 * 1024 * 1024
+
+// newLogDiskBuffer 创建磁盘缓冲
+func newLogDiskBuffer(dir string, maxBytes int64) *logDiskBuffer {
+	return &logDiskBuffer{dir: dir, maxBytes: maxBytes}
+}
+
+// Append 追加日志，返回因超出上限被丢弃的条数
+func (b *logDiskBuffer) Append(entries []LogEntry) (int64, error) {
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	var data bytes.Buffer
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			continue
+		}
+		data.Write(line)
+		data.WriteByte('\n')
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := os.MkdirAll(b.dir, 0755); err != nil {
+		return 0, fmt.Errorf("创建日志缓冲目录失败: %v", err)
+	}
+
+	if b.active != "" {
+		if info, err := os.Stat(b.active); err != nil || info.Size() >= logBufferSegmentSize {
+			b.active = ""
+		}
+	}
+	if b.active == "" {
+		b.active = filepath.Join(b.dir, fmt.Sprintf("%020d.ndjson", time.Now().UnixNano()))
+	}
+
+	file, err := os.OpenFile(b.active, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("打开日志缓冲文件失败: %v", err)
+	}
+	_, err = file.Write(data.Bytes())
+	file.Close()
+	if err != nil {
+		return 0, fmt.Errorf("写入日志缓冲失败: %v", err)
+	}
+
+	return b.enforceLimit(), nil
+}
+
+// Oldest 读取最旧的分段
+//
+// 只剩活动分段时先将其封存，保证读取期间不会有新数据追加到同一文件。
+func (b *logDiskBuffer) Oldest() (string, []LogEntry, error) {
+	b.mu.Lock()
+	segments := b.segments()
+	if len(segments) == 0 {
+		b.mu.Unlock()
+		return "", nil, nil
+	}
+	path := segments[0]
+	if path == b.active {
+		b.active = ""
+	}
+	b.mu.Unlock()
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer file.Close()
+
+	var entries []LogEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry LogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil {
+			entries = append(entries, entry)
+		}
+	}
+	return path, entries, scanner.Err()
+}
+
+// Rewrite 用剩余的日志覆盖分段
+func (b *logDiskBuffer) Rewrite(path string, entries []LogEntry) error {
+	var data bytes.Buffer
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			continue
+		}
+		data.Write(line)
+		data.WriteByte('\n')
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Remove 删除已重放完成的分段
+func (b *logDiskBuffer) Remove(path string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return os.Remove(path)
+}
+
+// Size 获取磁盘缓冲总大小
+func (b *logDiskBuffer) Size() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var total int64
+	for _, path := range b.segments() {
+		if info, err := os.Stat(path); err == nil {
+			total += info.Size()
+		}
+	}
+	return total
+}
+
+// segments 按时间顺序列出分段文件（调用方持有锁）
+func (b *logDiskBuffer) segments() []string {
+	matches, err := filepath.Glob(filepath.Join(b.dir, "*.ndjson"))
+	if err != nil {
+		return nil
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// enforceLimit 超出上限时删除最旧的分段（不删除活动分段），返回丢弃的条数（调用方持有锁）
+func (b *logDiskBuffer) enforceLimit() int64 {
+	if b.maxBytes <= 0 {
+		return 0
+	}
+
+	segments := b.segments()
+	sizes := make([]int64, len(segments))
+	var total int64
+	for i, path := range segments {
+		if info, err := os.Stat(path); err == nil {
+			sizes[i] = info.Size()
+			total += sizes[i]
+		}
+	}
+
+	var dropped int64
+	for i, path := range segments {
+		if total <= b.maxBytes || path == b.active {
+			break
+		}
+		if data, err := os.ReadFile(path); err == nil {
+			dropped += int64(bytes.Count(data, []byte{'\n'}))
+		}
+		if os.Remove(path) == nil {
+			total -= sizes[i]
+		}
+	}
+	return dropped
+}
@@ -0,0 +1,400 @@
+package Services
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 日志输出在出站HTTP客户端中的依赖名称，失败重试由日志传输器负责
+const (
+	elasticsearchLogOutputDependency = "log_shipping_elasticsearch"
+	lokiLogOutputDependency          = "log_shipping_loki"
+)
+
+// ElasticsearchLogOutput Elasticsearch 日志输出
+// 功能说明：
+// 1. 使用 _bulk 接口批量写入，索引名由模板中的 {logger} 和 {date} 生成
+// 2. 文档ID由日志内容哈希生成并使用 create 操作，重试产生的重复写入返回409并视为成功
+// 3. 多个节点地址时依次尝试，直到有节点成功响应
+type ElasticsearchLogOutput struct {
+	config *Config.LogShippingElasticsearchConfig
+	client *OutboundHTTPClient
+}
+
+// NewElasticsearchLogOutput 创建 Elasticsearch 日志输出
+func NewElasticsearchLogOutput(config *Config.LogShippingElasticsearchConfig) *ElasticsearchLogOutput {
+	return &ElasticsearchLogOutput{
+		config: config,
+		client: outboundClientFor(elasticsearchLogOutputDependency, 30*time.Second),
+	}
+}
+
+// Name 输出名称
+func (o *ElasticsearchLogOutput) Name() string {
+	return "elasticsearch"
+}
+
+// Send 批量写入日志
+func (o *ElasticsearchLogOutput) Send(ctx context.Context, entries []LogEntry) error {
+	var body bytes.Buffer
+	for _, entry := range entries {
+		doc, err := json.Marshal(struct {
+			LogEntry
+			Timestamp string `json:"@timestamp"`
+		}{entry, entry.Timestamp.UTC().Format(time.RFC3339Nano)})
+		if err != nil {
+			continue
+		}
+
+		hash := sha1.Sum(doc)
+		action, _ := json.Marshal(map[string]map[string]string{
+			"create": {"_index": o.indexName(entry), "_id": hex.EncodeToString(hash[:])},
+		})
+		body.Write(action)
+		body.WriteByte('\n')
+		body.Write(doc)
+		body.WriteByte('\n')
+	}
+	if body.Len() == 0 {
+		return nil
+	}
+
+	var lastErr error
+	for _, address := range o.config.Addresses {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(address, "/")+"/_bulk", bytes.NewReader(body.Bytes()))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/x-ndjson")
+		if o.config.APIKey != "" {
+			req.Header.Set("Authorization", "ApiKey "+o.config.APIKey)
+		} else if o.config.Username != "" {
+			req.SetBasicAuth(o.config.Username, o.config.Password)
+		}
+
+		resp, err := o.client.Do(elasticsearchLogOutputDependency, req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode >= 500 {
+			lastErr = fmt.Errorf("Elasticsearch返回状态码 %d", resp.StatusCode)
+			continue
+		}
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("Elasticsearch返回状态码 %d: %s", resp.StatusCode, truncateString(string(data), 200))
+		}
+		return checkBulkResponse(data)
+	}
+	return lastErr
+}
+
+// Close 关闭输出
+func (o *ElasticsearchLogOutput) Close() error {
+	return nil
+}
+
+// indexName 根据模板生成索引名
+func (o *ElasticsearchLogOutput) indexName(entry LogEntry) string {
+	logger := strings.ToLower(entry.Logger)
+	if logger == "" {
+		logger = "default"
+	}
+	return strings.NewReplacer(
+		"{logger}", logger,
+		"{date}", entry.Timestamp.UTC().Format("2006.01.02"),
+	).Replace(o.config.Index)
+}
+
+// checkBulkResponse 检查批量写入结果，409（文档已存在）视为成功
+func checkBulkResponse(data []byte) error {
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int `json:"status"`
+			Error  struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("解析Elasticsearch响应失败: %v", err)
+	}
+	if !result.Errors {
+		return nil
+	}
+
+	failed := 0
+	reason := ""
+	for _, item := range result.Items {
+		for _, status := range item {
+			if status.Status >= 300 && status.Status != http.StatusConflict {
+				failed++
+				if reason == "" {
+					reason = status.Error.Type + ": " + status.Error.Reason
+				}
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("Elasticsearch批量写入有%d条失败: %s", failed, reason)
+	}
+	return nil
+}
+
+// LokiLogOutput Grafana Loki 日志输出
+// 功能说明：
+// 1. 使用 /loki/api/v1/push 接口推送，按日志记录器和级别分成不同的流
+// 2. 流标签为 logger、level 和配置的静态标签，日志内容为JSON
+// 3. 配置了租户ID时通过 X-Scope-OrgID 请求头传递
+type LokiLogOutput struct {
+	config *Config.LogShippingLokiConfig
+	client *OutboundHTTPClient
+	labels map[string]string
+}
+
+// NewLokiLogOutput 创建 Loki 日志输出
+func NewLokiLogOutput(config *Config.LogShippingLokiConfig) *LokiLogOutput {
+	labels := make(map[string]string)
+	for _, label := range config.Labels {
+		if key, value, ok := strings.Cut(label, "="); ok && strings.TrimSpace(key) != "" {
+			labels[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	return &LokiLogOutput{
+		config: config,
+		client: outboundClientFor(lokiLogOutputDependency, 30*time.Second),
+		labels: labels,
+	}
+}
+
+// Name 输出名称
+func (o *LokiLogOutput) Name() string {
+	return "loki"
+}
+
+// Send 推送日志
+func (o *LokiLogOutput) Send(ctx context.Context, entries []LogEntry) error {
+	type stream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+
+	streams := make(map[string]*stream)
+	var keys []string
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			continue
+		}
+
+		key := entry.Logger + "\x00" + string(entry.Level)
+		s, ok := streams[key]
+		if !ok {
+			labels := make(map[string]string, len(o.labels)+2)
+			for k, v := range o.labels {
+				labels[k] = v
+			}
+			labels["logger"] = entry.Logger
+			labels["level"] = string(entry.Level)
+			s = &stream{Stream: labels}
+			streams[key] = s
+			keys = append(keys, key)
+		}
+		s.Values = append(s.Values, [2]string{strconv.FormatInt(entry.Timestamp.UnixNano(), 10), string(line)})
+	}
+	if len(streams) == 0 {
+		return nil
+	}
+
+	payload := struct {
+		Streams []*stream `json:"streams"`
+	}{}
+	for _, key := range keys {
+		s := streams[key]
+		// 同一个流内按时间排序，兼容未开启乱序写入的 Loki
+		sort.SliceStable(s.Values, func(i, j int) bool {
+			a, _ := strconv.ParseInt(s.Values[i][0], 10, 64)
+			b, _ := strconv.ParseInt(s.Values[j][0], 10, 64)
+			return a < b
+		})
+		payload.Streams = append(payload.Streams, s)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(o.config.URL, "/")+"/loki/api/v1/push", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.config.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", o.config.TenantID)
+	}
+	if o.config.Username != "" {
+		req.SetBasicAuth(o.config.Username, o.config.Password)
+	}
+
+	resp, err := o.client.Do(lokiLogOutputDependency, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Loki返回状态码 %d: %s", resp.StatusCode, truncateString(string(data), 200))
+	}
+	return nil
+}
+
+// Close 关闭输出
+func (o *LokiLogOutput) Close() error {
+	return nil
+}
+
+// SyslogLogOutput Syslog 日志输出
+// 功能说明：
+// 1. 按 RFC 5424 格式发送，MSGID 为日志记录器名称，消息内容为JSON
+// 2. UDP 每条日志一个数据报，TCP 使用 RFC 6587 的长度前缀分帧
+// 3. 连接按需建立，写入失败时断开，下次投递重新连接
+type SyslogLogOutput struct {
+	config   *Config.LogShippingSyslogConfig
+	hostname string
+	mu       sync.Mutex
+	conn     net.Conn
+}
+
+// NewSyslogLogOutput 创建 Syslog 日志输出
+func NewSyslogLogOutput(config *Config.LogShippingSyslogConfig) *SyslogLogOutput {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	return &SyslogLogOutput{config: config, hostname: hostname}
+}
+
+// Name 输出名称
+func (o *SyslogLogOutput) Name() string {
+	return "syslog"
+}
+
+// Send 发送日志
+func (o *SyslogLogOutput) Send(ctx context.Context, entries []LogEntry) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.conn == nil {
+		dialer := net.Dialer{Timeout: 10 * time.Second}
+		conn, err := dialer.DialContext(ctx, o.config.Network, o.config.Address)
+		if err != nil {
+			return fmt.Errorf("连接Syslog服务器失败: %v", err)
+		}
+		o.conn = conn
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		o.conn.SetWriteDeadline(deadline)
+	}
+
+	for _, entry := range entries {
+		message := o.format(entry)
+		if o.config.Network == "tcp" {
+			message = strconv.Itoa(len(message)) + " " + message
+		}
+		if _, err := o.conn.Write([]byte(message)); err != nil {
+			o.conn.Close()
+			o.conn = nil
+			return fmt.Errorf("写入Syslog失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// Close 关闭连接
+func (o *SyslogLogOutput) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.conn != nil {
+		err := o.conn.Close()
+		o.conn = nil
+		return err
+	}
+	return nil
+}
+
+// format 格式化为 RFC 5424 消息
+func (o *SyslogLogOutput) format(entry LogEntry) string {
+	priority := o.config.Facility*8 + syslogSeverity(entry.Level)
+
+	tag := o.config.Tag
+	if tag == "" {
+		tag = "-"
+	}
+	msgID := entry.Logger
+	if msgID == "" {
+		msgID = "-"
+	}
+
+	body, err := json.Marshal(entry)
+	if err != nil {
+		body = []byte(entry.Message)
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s %s %d %s - %s",
+		priority,
+		entry.Timestamp.UTC().Format(time.RFC3339Nano),
+		o.hostname,
+		tag,
+		os.Getpid(),
+		msgID,
+		body,
+	)
+}
+
+// syslogSeverity 日志级别对应的 Syslog 严重程度
+func syslogSeverity(level Config.LogLevel) int {
+	switch level {
+	case Config.LogLevelDebug:
+		return 7
+	case Config.LogLevelInfo:
+		return 6
+	case Config.LogLevelWarning:
+		return 4
+	case Config.LogLevelError:
+		return 3
+	case Config.LogLevelFatal:
+		return 2
+	default:
+		return 5
+	}
+}
+
+// truncateString 截断过长的响应内容，用于错误信息
+func truncateString(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "..."
+}
@@ -0,0 +1,258 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Utils"
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LoginRecord 一次登录尝试
+type LoginRecord struct {
+	Username      string // 登录使用的用户名
+	IPAddress     string // 客户端IP
+	UserAgent     string // 客户端 User-Agent
+	Country       string // 客户端国家代码，取自反向代理或CDN的请求头，未知时为空
+	Success       bool   // 是否登录成功
+	FailureReason string // 失败原因
+}
+
+// LoginHistoryEntry 用户登录记录
+type LoginHistoryEntry struct {
+	ID            uint      `json:"id"`
+	Time          time.Time `json:"time"`
+	IPAddress     string    `json:"ip_address"`
+	Location      string    `json:"location"` // 国家代码，未知时为空
+	Device        string    `json:"device"`   // 浏览器和操作系统，如 Chrome on Windows
+	UserAgent     string    `json:"user_agent"`
+	Success       bool      `json:"success"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+}
+
+// LoginHistoryService 登录历史和新设备登录提醒服务
+// 功能说明：
+// 1. 记录每次登录尝试，设备取自 User-Agent 解析出的浏览器和操作系统，位置取自请求头中的国家代码
+// 2. 登录成功时，设备或国家在已知时间窗口内没有成功登录过的视为新设备，向用户邮箱发送提醒，首次登录和用户关闭邮件通知时不提醒
+// 3. 用户可以分页查询自己的登录记录
+type LoginHistoryService struct {
+	db              *gorm.DB
+	config          *Config.LoginNotificationConfig
+	mailer          NotificationMailer
+	securityService *SecurityService
+	now             func() time.Time
+}
+
+// NewLoginHistoryService 创建登录历史服务
+//
+// config 为 nil 时使用全局配置；mailer 为 nil 且全局邮件服务已配置时使用 EmailService。
+func NewLoginHistoryService(db *gorm.DB, config *Config.LoginNotificationConfig, mailer NotificationMailer) *LoginHistoryService {
+	if config == nil {
+		if globalConfig := Config.GetConfig(); globalConfig != nil {
+			config = &globalConfig.Security.LoginNotification
+		} else {
+			securityConfig := &Config.SecurityConfig{}
+			securityConfig.SetDefaults()
+			config = &securityConfig.LoginNotification
+		}
+	}
+	if mailer == nil {
+		mailer = defaultNotificationMailer()
+	}
+
+	return &LoginHistoryService{
+		db:     db,
+		config: config,
+		mailer: mailer,
+		now:    time.Now,
+	}
+}
+
+// SetSecurityService 设置安全服务，设置后登录尝试通过 SecurityService 记录（计算风险评分并更新用户行为画像）
+func (s *LoginHistoryService) SetSecurityService(securityService *SecurityService) {
+	s.securityService = securityService
+}
+
+// SetClock 设置时钟，用于测试
+func (s *LoginHistoryService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// Country 从请求头中读取客户端国家代码，未配置请求头或请求头为空时返回空字符串
+func (s *LoginHistoryService) Country(header http.Header) string {
+	if s.config.CountryHeader == "" {
+		return ""
+	}
+	return header.Get(s.config.CountryHeader)
+}
+
+// Record 记录一次登录尝试，返回是否为新设备或新国家的成功登录
+// 新设备登录且用户有邮箱时异步发送提醒邮件
+func (s *LoginHistoryService) Record(ctx context.Context, record LoginRecord) (bool, error) {
+	device := Utils.ParseUserAgent(record.UserAgent).String()
+	country := strings.ToUpper(strings.TrimSpace(record.Country))
+	if country == "XX" {
+		// Cloudflare 无法识别国家时返回 XX
+		country = ""
+	}
+
+	newDevice := false
+	if record.Success {
+		var err error
+		if newDevice, err = s.isNewDevice(ctx, record.Username, device, country); err != nil {
+			return false, err
+		}
+	}
+
+	if s.securityService != nil {
+		if err := s.securityService.RecordLoginAttempt(ctx, record.Username, record.IPAddress, record.UserAgent,
+			record.FailureReason, record.Success, country, device); err != nil {
+			return false, err
+		}
+	} else {
+		attempt := Models.LoginAttempt{
+			Username:      record.Username,
+			IPAddress:     record.IPAddress,
+			UserAgent:     record.UserAgent,
+			Success:       record.Success,
+			FailureReason: record.FailureReason,
+			AttemptTime:   s.now(),
+			Location:      country,
+			DeviceInfo:    device,
+		}
+		if err := s.db.WithContext(ctx).Create(&attempt).Error; err != nil {
+			return false, err
+		}
+	}
+
+	if newDevice && s.config.EmailEnabled && s.mailer != nil {
+		var user Models.User
+		if err := s.db.WithContext(ctx).Where("username = ?", record.Username).First(&user).Error; err == nil && user.Email != "" {
+			go func() {
+				if err := s.sendNewDeviceEmail(&user, record, device, country); err != nil {
+					log.Printf("发送新设备登录提醒邮件失败: %v", err)
+				}
+			}()
+		}
+	}
+	return newDevice, nil
+}
+
+// isNewDevice 判断设备或国家在已知时间窗口内是否没有成功登录过，从未成功登录过的用户不算新设备
+func (s *LoginHistoryService) isNewDevice(ctx context.Context, username, device, country string) (bool, error) {
+	successful := func() *gorm.DB {
+		return s.db.WithContext(ctx).Model(&Models.LoginAttempt{}).Where("username = ? AND success = ?", username, true)
+	}
+
+	var total int64
+	if err := successful().Count(&total).Error; err != nil {
+		return false, err
+	}
+	if total == 0 {
+		return false, nil
+	}
+
+	since := s.now().Add(-s.config.KnownWindow)
+	var knownDevice int64
+	if err := successful().Where("attempt_time > ? AND device_info = ?", since, device).Count(&knownDevice).Error; err != nil {
+		return false, err
+	}
+	if knownDevice == 0 {
+		return true, nil
+	}
+	if country == "" {
+		return false, nil
+	}
+	var knownCountry int64
+	if err := successful().Where("attempt_time > ? AND location = ?", since, country).Count(&knownCountry).Error; err != nil {
+		return false, err
+	}
+	return knownCountry == 0, nil
+}
+
+// sendNewDeviceEmail 发送新设备登录提醒邮件，用户关闭邮件通知时不发送
+func (s *LoginHistoryService) sendNewDeviceEmail(user *Models.User, record LoginRecord, device, country string) error {
+	if country == "" {
+		country = "未知"
+	}
+	body := fmt.Sprintf(`<p>%s，您好：</p>
+<p>您的账户刚刚在一个新的设备或地区登录成功：</p>
+<ul>
+<li>时间：%s</li>
+<li>设备：%s</li>
+<li>IP地址：%s</li>
+<li>国家/地区：%s</li>
+</ul>
+<p>如果这是您本人的操作，请忽略此邮件；否则请立即修改密码并检查账户的登录记录。</p>`,
+		html.EscapeString(user.Username), s.now().Format("2006-01-02 15:04:05"),
+		html.EscapeString(device), html.EscapeString(record.IPAddress), html.EscapeString(country))
+
+	result := NewUserNotificationDispatcher(s.db, s.mailer).Dispatch(context.Background(), user, UserNotification{
+		EmailSubject: "新设备登录提醒",
+		EmailBody:    body,
+	})
+	return result.Failures[NotificationChannelEmail]
+}
+
+// History 分页查询用户的登录记录，按时间倒序
+func (s *LoginHistoryService) History(userID uint, req Utils.PageRequest) ([]LoginHistoryEntry, Utils.PageMeta, error) {
+	var user Models.User
+	if err := s.db.Select("id", "username").First(&user, userID).Error; err != nil {
+		return nil, Utils.PageMeta{}, err
+	}
+
+	var attempts []Models.LoginAttempt
+	query := s.db.Model(&Models.LoginAttempt{}).Where("username = ?", user.Username)
+	meta, err := Utils.Paginate(query, req, Utils.PageOrder{Column: "attempt_time"}, &attempts)
+	if err != nil {
+		return nil, meta, err
+	}
+
+	entries := make([]LoginHistoryEntry, 0, len(attempts))
+	for _, attempt := range attempts {
+		entries = append(entries, LoginHistoryEntry{
+			ID:            attempt.ID,
+			Time:          attempt.AttemptTime,
+			IPAddress:     attempt.IPAddress,
+			Location:      attempt.Location,
+			Device:        attempt.DeviceInfo,
+			UserAgent:     attempt.UserAgent,
+			Success:       attempt.Success,
+			FailureReason: attempt.FailureReason,
+		})
+	}
+	return entries, meta, nil
+}
+
+var (
+	defaultLoginHistoryService   *LoginHistoryService
+	defaultLoginHistoryServiceMu sync.RWMutex
+)
+
+// SetDefaultLoginHistoryService 设置全局登录历史服务
+func SetDefaultLoginHistoryService(service *LoginHistoryService) {
+	defaultLoginHistoryServiceMu.Lock()
+	defer defaultLoginHistoryServiceMu.Unlock()
+	defaultLoginHistoryService = service
+}
+
+// DefaultLoginHistoryService 获取全局登录历史服务，未设置时使用 db 创建，db 为 nil 时返回 nil
+func DefaultLoginHistoryService(db *gorm.DB) *LoginHistoryService {
+	defaultLoginHistoryServiceMu.RLock()
+	defer defaultLoginHistoryServiceMu.RUnlock()
+	if defaultLoginHistoryService != nil {
+		return defaultLoginHistoryService
+	}
+	if db == nil {
+		return nil
+	}
+	return NewLoginHistoryService(db, nil, nil)
+}
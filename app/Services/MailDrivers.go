@@ -0,0 +1,668 @@
+package Services
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MailDriver 邮件发送驱动
+//
+// Send 返回服务商的消息ID；失败时返回 *MailDeliveryError 区分临时失败和永久失败。
+type MailDriver interface {
+	Name() string
+	Send(ctx context.Context, message *Mail) (string, error)
+}
+
+// MailDeliveryError 邮件发送错误
+type MailDeliveryError struct {
+	Permanent bool   // 是否永久失败（不再重试）
+	Recipient string // 被拒收的收件人，硬退信时加入屏蔽列表
+	Err       error
+}
+
+func (e *MailDeliveryError) Error() string {
+	if e.Recipient != "" {
+		return fmt.Sprintf("%s: %v", e.Recipient, e.Err)
+	}
+	return e.Err.Error()
+}
+
+func (e *MailDeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// IsPermanentMailError 是否为永久失败，未包装的错误（网络错误等）按临时失败处理
+func IsPermanentMailError(err error) bool {
+	var deliveryErr *MailDeliveryError
+	return errors.As(err, &deliveryErr) && deliveryErr.Permanent
+}
+
+// NewMailDriver 按配置创建发送驱动
+//
+// client 为 nil 时使用全局出站HTTP客户端，只有API类驱动（SendGrid、SES、Mailgun）使用。
+func NewMailDriver(config *Config.EmailConfig, client *OutboundHTTPClient) (MailDriver, error) {
+	driver := config.GetDriver()
+	if driver == Config.MailDriverSMTP {
+		return newSMTPMailDriver(config.Host, config.Port, config.Username, config.Password, config.UseTLS, config.Timeout), nil
+	}
+	if driver == Config.MailDriverLog {
+		return &logMailDriver{}, nil
+	}
+
+	if client == nil {
+		client = GetOutboundHTTPClient()
+	}
+	// 重试由邮件队列负责，出站客户端不再重试
+	policy := client.DefaultPolicy()
+	policy.MaxRetries = 0
+	if config.Timeout > 0 {
+		policy.Timeout = config.Timeout
+	}
+	client.SetPolicy(mailDependency(driver), policy)
+
+	switch driver {
+	case Config.MailDriverSendGrid:
+		return &sendGridMailDriver{apiKey: config.APIKey, endpoint: mailEndpoint(config.Endpoint, "https://api.sendgrid.com"), client: client}, nil
+	case Config.MailDriverMailgun:
+		base := "https://api.mailgun.net"
+		if strings.EqualFold(config.Region, "eu") {
+			base = "https://api.eu.mailgun.net"
+		}
+		return &mailgunMailDriver{apiKey: config.APIKey, domain: config.Domain, endpoint: mailEndpoint(config.Endpoint, base), client: client}, nil
+	case Config.MailDriverSES:
+		return &sesMailDriver{
+			region:          config.Region,
+			accessKeyID:     config.AccessKeyID,
+			secretAccessKey: config.SecretAccessKey,
+			endpoint:        mailEndpoint(config.Endpoint, fmt.Sprintf("https://email.%s.amazonaws.com", config.Region)),
+			client:          client,
+		}, nil
+	default:
+		return nil, fmt.Errorf("不支持的邮件驱动: %s", driver)
+	}
+}
+
+// mailDependency 邮件服务商在出站HTTP客户端中的依赖名称
+func mailDependency(driver string) string {
+	return "mail_" + driver
+}
+
+func mailEndpoint(configured, fallback string) string {
+	if configured != "" {
+		return strings.TrimRight(configured, "/")
+	}
+	return fallback
+}
+
+// mailHTTPError 将服务商的HTTP响应转换为发送错误，429和5xx为临时失败，其余4xx为永久失败
+func mailHTTPError(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err := fmt.Errorf("服务商返回状态码 %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	permanent := resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests
+	return &MailDeliveryError{Permanent: permanent, Err: err}
+}
+
+// ---------------------------------------------------------------------------
+// MIME 构建
+// ---------------------------------------------------------------------------
+
+// mimeEntity MIME实体（头部和已编码的内容）
+type mimeEntity struct {
+	header textproto.MIMEHeader
+	body   []byte
+}
+
+// buildMIMEMessage 构建完整的MIME邮件，密送收件人不写入头部
+func buildMIMEMessage(message *Mail, messageID string) ([]byte, error) {
+	entity, err := mailBodyEntity(message)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writeHeader := func(key, value string) {
+		if value != "" {
+			fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+		}
+	}
+	writeHeader("From", formatMailAddress(message.From, message.FromName))
+	writeHeader("To", strings.Join(message.To, ", "))
+	writeHeader("Cc", strings.Join(message.Cc, ", "))
+	writeHeader("Reply-To", message.ReplyTo)
+	writeHeader("Subject", mime.QEncoding.Encode("UTF-8", message.Subject))
+	writeHeader("Date", time.Now().Format(time.RFC1123Z))
+	writeHeader("Message-ID", "<"+messageID+">")
+	for key, value := range message.Headers {
+		writeHeader(textproto.CanonicalMIMEHeaderKey(key), mime.QEncoding.Encode("UTF-8", value))
+	}
+	writeHeader("MIME-Version", "1.0")
+	for key, values := range entity.header {
+		writeHeader(key, strings.Join(values, ", "))
+	}
+	buf.WriteString("\r\n")
+	buf.Write(entity.body)
+	return buf.Bytes(), nil
+}
+
+// mailBodyEntity 生成邮件正文：纯文本和HTML同时存在时使用 multipart/alternative，有附件时再包一层 multipart/mixed
+func mailBodyEntity(message *Mail) (mimeEntity, error) {
+	var parts []mimeEntity
+	if message.Text != "" {
+		parts = append(parts, textMIMEEntity("text/plain", message.Text))
+	}
+	if message.HTML != "" {
+		parts = append(parts, textMIMEEntity("text/html", message.HTML))
+	}
+
+	var body mimeEntity
+	switch len(parts) {
+	case 0:
+		body = textMIMEEntity("text/plain", "")
+	case 1:
+		body = parts[0]
+	default:
+		alternative, err := multipartMIMEEntity("alternative", parts)
+		if err != nil {
+			return mimeEntity{}, err
+		}
+		body = alternative
+	}
+
+	if len(message.Attachments) == 0 {
+		return body, nil
+	}
+	mixed := []mimeEntity{body}
+	for _, attachment := range message.Attachments {
+		mixed = append(mixed, attachmentMIMEEntity(attachment))
+	}
+	return multipartMIMEEntity("mixed", mixed)
+}
+
+func textMIMEEntity(contentType, content string) mimeEntity {
+	var buf bytes.Buffer
+	writer := quotedprintable.NewWriter(&buf)
+	writer.Write([]byte(content))
+	writer.Close()
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType+"; charset=UTF-8")
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	return mimeEntity{header: header, body: buf.Bytes()}
+}
+
+func attachmentMIMEEntity(attachment MailAttachment) mimeEntity {
+	contentType := attachment.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(attachment.Content)
+	var buf bytes.Buffer
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded)
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", mime.FormatMediaType(contentType, map[string]string{"name": attachment.Filename}))
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	header.Set("Content-Transfer-Encoding", "base64")
+	return mimeEntity{header: header, body: buf.Bytes()}
+}
+
+func multipartMIMEEntity(subtype string, parts []mimeEntity) (mimeEntity, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for _, part := range parts {
+		w, err := writer.CreatePart(part.header)
+		if err != nil {
+			return mimeEntity{}, err
+		}
+		if _, err := w.Write(part.body); err != nil {
+			return mimeEntity{}, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return mimeEntity{}, err
+	}
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", "multipart/"+subtype+"; boundary="+writer.Boundary())
+	return mimeEntity{header: header, body: buf.Bytes()}, nil
+}
+
+// mailMessageID 使用邮件的内部ID作为 Message-ID，未入库直接发送的邮件临时生成
+func mailMessageID(message *Mail) string {
+	if message.ID != "" {
+		return message.ID
+	}
+	return newMailMessageID(message.From)
+}
+
+func formatMailAddress(address, name string) string {
+	if name == "" {
+		return address
+	}
+	return (&mail.Address{Name: name, Address: address}).String()
+}
+
+// ---------------------------------------------------------------------------
+// SMTP
+// ---------------------------------------------------------------------------
+
+// smtpMailDriver SMTP发送驱动，465端口使用隐式TLS，其他端口在 useTLS 时使用 STARTTLS
+type smtpMailDriver struct {
+	host     string
+	port     int
+	username string
+	password string
+	useTLS   bool
+	timeout  time.Duration
+}
+
+func newSMTPMailDriver(host string, port int, username, password string, useTLS bool, timeout time.Duration) *smtpMailDriver {
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &smtpMailDriver{host: host, port: port, username: username, password: password, useTLS: useTLS, timeout: timeout}
+}
+
+func (d *smtpMailDriver) Name() string {
+	return Config.MailDriverSMTP
+}
+
+func (d *smtpMailDriver) Send(ctx context.Context, message *Mail) (string, error) {
+	messageID := mailMessageID(message)
+	data, err := buildMIMEMessage(message, messageID)
+	if err != nil {
+		return "", &MailDeliveryError{Permanent: true, Err: err}
+	}
+
+	addr := net.JoinHostPort(d.host, strconv.Itoa(d.port))
+	dialer := &net.Dialer{Timeout: d.timeout}
+	var conn net.Conn
+	if d.port == 465 {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: d.host})
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return "", err
+	}
+	deadline := time.Now().Add(d.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, d.host)
+	if err != nil {
+		conn.Close()
+		return "", err
+	}
+	defer client.Close()
+
+	if d.useTLS && d.port != 465 {
+		if err := client.StartTLS(&tls.Config{ServerName: d.host}); err != nil {
+			return "", err
+		}
+	}
+	if d.username != "" {
+		if ok, _ := client.Extension("AUTH"); ok {
+			if err := client.Auth(smtp.PlainAuth("", d.username, d.password, d.host)); err != nil {
+				return "", smtpDeliveryError("", err)
+			}
+		}
+	}
+
+	if err := client.Mail(message.From); err != nil {
+		return "", smtpDeliveryError("", err)
+	}
+	for _, recipient := range message.Recipients() {
+		if err := client.Rcpt(recipient); err != nil {
+			return "", smtpDeliveryError(recipient, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return "", smtpDeliveryError("", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", smtpDeliveryError("", err)
+	}
+	client.Quit()
+
+	return messageID, nil
+}
+
+// smtpDeliveryError 5xx响应为永久失败，收件人被拒收时记录收件人
+func smtpDeliveryError(recipient string, err error) error {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+		return &MailDeliveryError{Permanent: true, Recipient: recipient, Err: err}
+	}
+	return err
+}
+
+// ---------------------------------------------------------------------------
+// SendGrid
+// ---------------------------------------------------------------------------
+
+type sendGridMailDriver struct {
+	apiKey   string
+	endpoint string
+	client   *OutboundHTTPClient
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+func (d *sendGridMailDriver) Name() string {
+	return Config.MailDriverSendGrid
+}
+
+func (d *sendGridMailDriver) Send(ctx context.Context, message *Mail) (string, error) {
+	addresses := func(list []string) []sendGridAddress {
+		result := make([]sendGridAddress, 0, len(list))
+		for _, address := range list {
+			result = append(result, sendGridAddress{Email: address})
+		}
+		return result
+	}
+
+	personalization := map[string]interface{}{"to": addresses(message.To)}
+	if len(message.Cc) > 0 {
+		personalization["cc"] = addresses(message.Cc)
+	}
+	if len(message.Bcc) > 0 {
+		personalization["bcc"] = addresses(message.Bcc)
+	}
+
+	// SendGrid 要求 text/plain 在 text/html 之前
+	var content []map[string]string
+	if message.Text != "" {
+		content = append(content, map[string]string{"type": "text/plain", "value": message.Text})
+	}
+	if message.HTML != "" {
+		content = append(content, map[string]string{"type": "text/html", "value": message.HTML})
+	}
+
+	payload := map[string]interface{}{
+		"personalizations": []interface{}{personalization},
+		"from":             sendGridAddress{Email: message.From, Name: message.FromName},
+		"subject":          message.Subject,
+		"content":          content,
+	}
+	if message.ReplyTo != "" {
+		payload["reply_to"] = sendGridAddress{Email: message.ReplyTo}
+	}
+	if len(message.Headers) > 0 {
+		payload["headers"] = message.Headers
+	}
+	if message.ID != "" {
+		payload["custom_args"] = map[string]string{"message_id": message.ID}
+	}
+	if len(message.Attachments) > 0 {
+		attachments := make([]map[string]string, 0, len(message.Attachments))
+		for _, attachment := range message.Attachments {
+			attachments = append(attachments, map[string]string{
+				"content":     base64.StdEncoding.EncodeToString(attachment.Content),
+				"filename":    attachment.Filename,
+				"type":        attachment.ContentType,
+				"disposition": "attachment",
+			})
+		}
+		payload["attachments"] = attachments
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", &MailDeliveryError{Permanent: true, Err: err}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.endpoint+"/v3/mail/send", bytes.NewReader(body))
+	if err != nil {
+		return "", &MailDeliveryError{Permanent: true, Err: err}
+	}
+	req.Header.Set("Authorization", "Bearer "+d.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.client.Do(mailDependency(Config.MailDriverSendGrid), req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := mailHTTPError(resp); err != nil {
+		return "", err
+	}
+	return resp.Header.Get("X-Message-Id"), nil
+}
+
+// ---------------------------------------------------------------------------
+// Mailgun
+// ---------------------------------------------------------------------------
+
+type mailgunMailDriver struct {
+	apiKey   string
+	domain   string
+	endpoint string
+	client   *OutboundHTTPClient
+}
+
+func (d *mailgunMailDriver) Name() string {
+	return Config.MailDriverMailgun
+}
+
+func (d *mailgunMailDriver) Send(ctx context.Context, message *Mail) (string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	field := func(name, value string) {
+		if value != "" {
+			writer.WriteField(name, value)
+		}
+	}
+	field("from", formatMailAddress(message.From, message.FromName))
+	for _, address := range message.To {
+		field("to", address)
+	}
+	for _, address := range message.Cc {
+		field("cc", address)
+	}
+	for _, address := range message.Bcc {
+		field("bcc", address)
+	}
+	field("subject", message.Subject)
+	field("text", message.Text)
+	field("html", message.HTML)
+	field("h:Reply-To", message.ReplyTo)
+	for key, value := range message.Headers {
+		field("h:"+key, value)
+	}
+	field("v:message_id", message.ID)
+	for _, attachment := range message.Attachments {
+		w, err := writer.CreateFormFile("attachment", attachment.Filename)
+		if err != nil {
+			return "", &MailDeliveryError{Permanent: true, Err: err}
+		}
+		w.Write(attachment.Content)
+	}
+	if err := writer.Close(); err != nil {
+		return "", &MailDeliveryError{Permanent: true, Err: err}
+	}
+
+	url := fmt.Sprintf("%s/v3/%s/messages", d.endpoint, d.domain)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return "", &MailDeliveryError{Permanent: true, Err: err}
+	}
+	req.SetBasicAuth("api", d.apiKey)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := d.client.Do(mailDependency(Config.MailDriverMailgun), req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := mailHTTPError(resp); err != nil {
+		return "", err
+	}
+
+	var result struct {
+		ID string `json:"id"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	return strings.Trim(result.ID, "<>"), nil
+}
+
+// ---------------------------------------------------------------------------
+// Amazon SES (v2 API，原始MIME邮件，支持附件)
+// ---------------------------------------------------------------------------
+
+type sesMailDriver struct {
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+	endpoint        string
+	client          *OutboundHTTPClient
+}
+
+func (d *sesMailDriver) Name() string {
+	return Config.MailDriverSES
+}
+
+func (d *sesMailDriver) Send(ctx context.Context, message *Mail) (string, error) {
+	raw, err := buildMIMEMessage(message, mailMessageID(message))
+	if err != nil {
+		return "", &MailDeliveryError{Permanent: true, Err: err}
+	}
+
+	destination := map[string][]string{"ToAddresses": message.To}
+	if len(message.Cc) > 0 {
+		destination["CcAddresses"] = message.Cc
+	}
+	if len(message.Bcc) > 0 {
+		destination["BccAddresses"] = message.Bcc
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"FromEmailAddress": message.From,
+		"Destination":      destination,
+		"Content":          map[string]interface{}{"Raw": map[string]string{"Data": base64.StdEncoding.EncodeToString(raw)}},
+	})
+	if err != nil {
+		return "", &MailDeliveryError{Permanent: true, Err: err}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.endpoint+"/v2/email/outbound-emails", bytes.NewReader(body))
+	if err != nil {
+		return "", &MailDeliveryError{Permanent: true, Err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	signAWSRequest(req, body, d.accessKeyID, d.secretAccessKey, d.region, "ses", time.Now())
+
+	resp, err := d.client.Do(mailDependency(Config.MailDriverSES), req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := mailHTTPError(resp); err != nil {
+		return "", err
+	}
+
+	var result struct {
+		MessageID string `json:"MessageId"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	return result.MessageID, nil
+}
+
+// signAWSRequest 使用 AWS Signature Version 4 签名请求
+func signAWSRequest(req *http.Request, body []byte, accessKeyID, secretAccessKey, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "content-type;host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := fmt.Sprintf("content-type:%s\nhost:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n",
+		strings.TrimSpace(req.Header.Get("Content-Type")), req.URL.Host, payloadHash, amzDate)
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method, path, req.URL.Query().Encode(), canonicalHeaders, signedHeaders, payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, region, service)
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKeyID, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// ---------------------------------------------------------------------------
+// Log
+// ---------------------------------------------------------------------------
+
+// logMailDriver 只记录日志不发送
+type logMailDriver struct{}
+
+func (d *logMailDriver) Name() string {
+	return Config.MailDriverLog
+}
+
+func (d *logMailDriver) Send(ctx context.Context, message *Mail) (string, error) {
+	messageID := mailMessageID(message)
+	log.Printf("[mail] to=%s subject=%q attachments=%d message_id=%s",
+		strings.Join(message.Recipients(), ","), message.Subject, len(message.Attachments), messageID)
+	return messageID, nil
+}
@@ -0,0 +1,852 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrMailNoRecipients 邮件没有收件人
+	ErrMailNoRecipients = errors.New("邮件没有收件人")
+	// ErrMailNotRetryable 只有发送失败或等待重试的邮件可以手动重试
+	ErrMailNotRetryable = errors.New("邮件当前状态不可重试")
+	// ErrMailSuppressionNotFound 屏蔽列表中没有该地址
+	ErrMailSuppressionNotFound = errors.New("屏蔽列表中没有该地址")
+	// ErrUnsupportedMailProvider 不支持的退信回调服务商
+	ErrUnsupportedMailProvider = errors.New("不支持的邮件服务商")
+)
+
+// MailAttachment 邮件附件
+type MailAttachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Content     []byte `json:"content"`
+}
+
+// Mail 待发送的邮件
+//
+// 指定 Template 时入队前会渲染模板，主题为空时使用模板中定义的主题；Data 只用于渲染，不会持久化。
+type Mail struct {
+	ID          string                 `json:"id"`
+	From        string                 `json:"from"`
+	FromName    string                 `json:"from_name,omitempty"`
+	To          []string               `json:"to"`
+	Cc          []string               `json:"cc,omitempty"`
+	Bcc         []string               `json:"bcc,omitempty"`
+	ReplyTo     string                 `json:"reply_to,omitempty"`
+	Subject     string                 `json:"subject"`
+	HTML        string                 `json:"html,omitempty"`
+	Text        string                 `json:"text,omitempty"`
+	Template    string                 `json:"template,omitempty"`
+	Layout      string                 `json:"layout,omitempty"`
+	Data        map[string]interface{} `json:"-"`
+	Headers     map[string]string      `json:"headers,omitempty"`
+	Attachments []MailAttachment       `json:"attachments,omitempty"`
+}
+
+// Attach 添加附件
+func (m *Mail) Attach(filename, contentType string, content []byte) *Mail {
+	m.Attachments = append(m.Attachments, MailAttachment{Filename: filename, ContentType: contentType, Content: content})
+	return m
+}
+
+// AttachFile 读取文件作为附件，按扩展名推断类型
+func (m *Mail) AttachFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	m.Attach(filepath.Base(path), mime.TypeByExtension(filepath.Ext(path)), content)
+	return nil
+}
+
+// Recipients 全部收件人（收件人、抄送、密送）
+func (m *Mail) Recipients() []string {
+	recipients := make([]string, 0, len(m.To)+len(m.Cc)+len(m.Bcc))
+	recipients = append(recipients, m.To...)
+	recipients = append(recipients, m.Cc...)
+	return append(recipients, m.Bcc...)
+}
+
+// MailMessageFilter 邮件投递记录查询条件
+type MailMessageFilter struct {
+	Status    string
+	Recipient string
+	Page      int
+	Limit     int
+}
+
+// MailService 邮件发送服务
+// 功能说明：
+// 1. 通过可切换的驱动发送邮件（SMTP、SendGrid、SES、Mailgun、log）
+// 2. 支持HTML模板和布局、附件
+// 3. 邮件先写入投递记录再由后台协程异步发送，临时失败按指数退避重试，服务重启后恢复未完成的邮件
+// 4. 维护屏蔽列表：硬退信、投诉的地址不再发送，服务商退信回调会更新屏蔽列表和投递状态
+type MailService struct {
+	db       *gorm.DB
+	config   *Config.EmailConfig
+	driver   MailDriver
+	renderer *MailTemplateRenderer
+
+	mu      sync.Mutex
+	started bool
+	queue   chan uint
+	stopCh  chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewMailService 创建邮件发送服务
+//
+// config 为 nil 时使用全局配置；驱动创建失败时记录日志并退回 log 驱动，避免邮件丢失前无记录。
+func NewMailService(db *gorm.DB, config *Config.EmailConfig) *MailService {
+	if config == nil {
+		if globalConfig := Config.GetConfig(); globalConfig != nil {
+			config = &globalConfig.Email
+		} else {
+			config = &Config.EmailConfig{}
+			config.SetDefaults()
+		}
+	}
+
+	driver, err := NewMailDriver(config, nil)
+	if err != nil {
+		log.Printf("创建邮件驱动失败，使用log驱动: %v", err)
+		driver = &logMailDriver{}
+	}
+
+	return &MailService{
+		db:       db,
+		config:   config,
+		driver:   driver,
+		renderer: NewMailTemplateRenderer(config.TemplatePath),
+	}
+}
+
+// SetDriver 替换发送驱动
+func (s *MailService) SetDriver(driver MailDriver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.driver = driver
+}
+
+// Renderer 获取模板渲染器
+func (s *MailService) Renderer() *MailTemplateRenderer {
+	return s.renderer
+}
+
+// Start 启动发送协程，并恢复上次未发送完成的邮件
+func (s *MailService) Start() {
+	s.mu.Lock()
+	if s.started {
+		s.mu.Unlock()
+		return
+	}
+	size := s.config.QueueSize
+	if size <= 0 {
+		size = 1000
+	}
+	workers := s.config.QueueWorkers
+	if workers <= 0 {
+		workers = 1
+	}
+	s.queue = make(chan uint, size)
+	s.stopCh = make(chan struct{})
+	s.started = true
+	s.mu.Unlock()
+
+	for i := 0; i < workers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+
+	// 上次退出时正在发送的邮件无法确认结果，按待重试处理
+	s.db.Model(&Models.MailMessage{}).Where("status = ?", Models.MailStatusSending).
+		Update("status", Models.MailStatusRetrying)
+
+	s.wg.Add(1)
+	go s.sweeper()
+}
+
+// Stop 停止发送协程，等待正在发送的邮件完成
+func (s *MailService) Stop() {
+	s.mu.Lock()
+	if !s.started {
+		s.mu.Unlock()
+		return
+	}
+	s.started = false
+	close(s.stopCh)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *MailService) worker() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case id := <-s.queue:
+			if err := s.deliver(context.Background(), id); err != nil {
+				log.Printf("发送邮件失败: id=%d, error=%v", id, err)
+			}
+		}
+	}
+}
+
+// sweeper 定期把到期的待发送、待重试邮件放回队列，处理队列满和服务重启的情况
+func (s *MailService) sweeper() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		s.enqueueDue()
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *MailService) enqueueDue() {
+	var ids []uint
+	err := s.db.Model(&Models.MailMessage{}).
+		Where("status IN ?", []string{Models.MailStatusQueued, Models.MailStatusRetrying}).
+		Where("next_attempt_at IS NULL OR next_attempt_at <= ?", time.Now()).
+		Order("id").Limit(cap(s.queue)).
+		Pluck("id", &ids).Error
+	if err != nil {
+		log.Printf("查询待发送邮件失败: %v", err)
+		return
+	}
+	for _, id := range ids {
+		s.enqueue(id)
+	}
+}
+
+// enqueue 放入发送队列，服务未启动或队列已满时邮件保留在数据库中，由 sweeper 稍后处理
+func (s *MailService) enqueue(id uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.started {
+		return
+	}
+	select {
+	case s.queue <- id:
+	default:
+		log.Printf("邮件发送队列已满，稍后重试: id=%d", id)
+	}
+}
+
+// Queue 保存邮件并放入异步发送队列
+func (s *MailService) Queue(message *Mail) (*Models.MailMessage, error) {
+	record, err := s.create(message)
+	if err != nil {
+		return nil, err
+	}
+	if record.Status == Models.MailStatusQueued {
+		s.enqueue(record.ID)
+	}
+	return record, nil
+}
+
+// Send 保存邮件并立即发送一次，临时失败时仍会按重试策略在后台重试
+func (s *MailService) Send(ctx context.Context, message *Mail) (*Models.MailMessage, error) {
+	record, err := s.create(message)
+	if err != nil {
+		return nil, err
+	}
+	begin := time.Now()
+	if record.Status == Models.MailStatusQueued {
+		err = s.deliver(ctx, record.ID)
+	}
+	if reloaded, getErr := s.GetMessage(record.ID); getErr == nil {
+		record = reloaded
+	}
+	DebugRecorderFromContext(ctx).RecordNotification(DebugNotification{
+		Channel:    "mail",
+		Recipient:  record.Recipients,
+		Subject:    record.Subject,
+		Status:     record.Status,
+		DurationMs: debugMs(time.Since(begin)),
+		Error:      record.LastError,
+	}, begin)
+	return record, err
+}
+
+// SendNotificationEmail 发送HTML通知邮件（异步），实现 NotificationMailer
+func (s *MailService) SendNotificationEmail(to, subject, body string) error {
+	_, err := s.Queue(&Mail{To: []string{to}, Subject: subject, HTML: body})
+	return err
+}
+
+// create 渲染模板、校验收件人并写入投递记录
+func (s *MailService) create(message *Mail) (*Models.MailMessage, error) {
+	if err := s.prepare(message); err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	record := &Models.MailMessage{
+		MessageID:  message.ID,
+		Driver:     s.currentDriver().Name(),
+		Recipients: strings.Join(message.Recipients(), ","),
+		Subject:    message.Subject,
+		Template:   message.Template,
+		Payload:    string(payload),
+		Status:     Models.MailStatusQueued,
+	}
+	if len(s.filterSuppressed(message)) == 0 {
+		record.Status = Models.MailStatusSuppressed
+		record.LastError = "收件人均在屏蔽列表中"
+	}
+	if err := s.db.Create(record).Error; err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (s *MailService) prepare(message *Mail) error {
+	if message.From == "" {
+		message.From = s.config.From
+		if message.FromName == "" {
+			message.FromName = s.config.FromName
+		}
+	}
+
+	var err error
+	normalize := func(list []string) []string {
+		result := make([]string, 0, len(list))
+		for _, address := range list {
+			parsed, parseErr := mail.ParseAddress(address)
+			if parseErr != nil {
+				err = fmt.Errorf("收件人地址无效: %s", address)
+				continue
+			}
+			result = append(result, parsed.Address)
+		}
+		return result
+	}
+	message.To = normalize(message.To)
+	message.Cc = normalize(message.Cc)
+	message.Bcc = normalize(message.Bcc)
+	if err != nil {
+		return err
+	}
+	if len(message.Recipients()) == 0 {
+		return ErrMailNoRecipients
+	}
+
+	if message.Template != "" {
+		subject, html, text, renderErr := s.renderer.Render(message.Template, message.Layout, message.Data)
+		if renderErr != nil {
+			return renderErr
+		}
+		if message.Subject == "" {
+			message.Subject = subject
+		}
+		message.HTML = html
+		if message.Text == "" {
+			message.Text = text
+		}
+	}
+
+	if message.ID == "" {
+		message.ID = newMailMessageID(message.From)
+	}
+	return nil
+}
+
+func (s *MailService) currentDriver() MailDriver {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.driver
+}
+
+// deliver 发送一次指定邮件，通过条件更新领取邮件，避免多个协程重复发送
+func (s *MailService) deliver(ctx context.Context, id uint) error {
+	result := s.db.Model(&Models.MailMessage{}).
+		Where("id = ? AND status IN ?", id, []string{Models.MailStatusQueued, Models.MailStatusRetrying}).
+		Updates(map[string]interface{}{
+			"status":   Models.MailStatusSending,
+			"attempts": gorm.Expr("attempts + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	var record Models.MailMessage
+	if err := s.db.First(&record, id).Error; err != nil {
+		return err
+	}
+	var message Mail
+	if err := json.Unmarshal([]byte(record.Payload), &message); err != nil {
+		s.finish(&record, Models.MailStatusFailed, "", err)
+		return err
+	}
+
+	// 入队后才加入屏蔽列表的地址也要过滤
+	recipients := s.filterSuppressed(&message)
+	if len(recipients) == 0 {
+		s.finish(&record, Models.MailStatusSuppressed, "", errors.New("收件人均在屏蔽列表中"))
+		return nil
+	}
+	message.To = keepMailRecipients(message.To, recipients)
+	message.Cc = keepMailRecipients(message.Cc, recipients)
+	message.Bcc = keepMailRecipients(message.Bcc, recipients)
+
+	timeout := s.config.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	sendCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	driver := s.currentDriver()
+	providerID, err := driver.Send(sendCtx, &message)
+	if err == nil {
+		s.finish(&record, Models.MailStatusSent, providerID, nil)
+		return nil
+	}
+
+	var deliveryErr *MailDeliveryError
+	if errors.As(err, &deliveryErr) && deliveryErr.Permanent && deliveryErr.Recipient != "" {
+		if suppressErr := s.Suppress(deliveryErr.Recipient, Models.MailSuppressionBounce, driver.Name(), err.Error()); suppressErr != nil {
+			log.Printf("加入邮件屏蔽列表失败: %v", suppressErr)
+		}
+		// 被拒收的地址已屏蔽，其余收件人继续重试
+		if len(recipients) > 1 && record.Attempts <= s.config.MaxRetries {
+			s.scheduleRetry(&record, err)
+			return err
+		}
+	}
+
+	if IsPermanentMailError(err) || record.Attempts > s.config.MaxRetries {
+		s.finish(&record, Models.MailStatusFailed, "", err)
+		return err
+	}
+	s.scheduleRetry(&record, err)
+	return err
+}
+
+// scheduleRetry 标记为待重试，延迟按 RetryDelay * 2^(n-1) 增长
+func (s *MailService) scheduleRetry(record *Models.MailMessage, cause error) {
+	delay := s.config.RetryDelay
+	if delay <= 0 {
+		delay = 30 * time.Second
+	}
+	for i := 1; i < record.Attempts && delay < 24*time.Hour; i++ {
+		delay *= 2
+	}
+	nextAttempt := time.Now().Add(delay)
+
+	err := s.db.Model(&Models.MailMessage{}).Where("id = ?", record.ID).Updates(map[string]interface{}{
+		"status":          Models.MailStatusRetrying,
+		"last_error":      cause.Error(),
+		"next_attempt_at": nextAttempt,
+	}).Error
+	if err != nil {
+		log.Printf("更新邮件投递状态失败: id=%d, error=%v", record.ID, err)
+		return
+	}
+
+	id := record.ID
+	time.AfterFunc(delay, func() { s.enqueue(id) })
+}
+
+func (s *MailService) finish(record *Models.MailMessage, status, providerID string, cause error) {
+	updates := map[string]interface{}{
+		"status":          status,
+		"next_attempt_at": nil,
+		"last_error":      "",
+	}
+	if cause != nil {
+		updates["last_error"] = cause.Error()
+	}
+	if status == Models.MailStatusSent {
+		updates["sent_at"] = time.Now()
+		updates["provider_message_id"] = providerID
+	}
+	if err := s.db.Model(&Models.MailMessage{}).Where("id = ?", record.ID).Updates(updates).Error; err != nil {
+		log.Printf("更新邮件投递状态失败: id=%d, error=%v", record.ID, err)
+	}
+}
+
+// Retry 手动重试发送失败的邮件，重置重试次数
+func (s *MailService) Retry(id uint) (*Models.MailMessage, error) {
+	result := s.db.Model(&Models.MailMessage{}).
+		Where("id = ? AND status IN ?", id, []string{Models.MailStatusFailed, Models.MailStatusRetrying}).
+		Updates(map[string]interface{}{
+			"status":          Models.MailStatusQueued,
+			"attempts":        0,
+			"next_attempt_at": nil,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		if _, err := s.GetMessage(id); err != nil {
+			return nil, err
+		}
+		return nil, ErrMailNotRetryable
+	}
+
+	s.enqueue(id)
+	return s.GetMessage(id)
+}
+
+// GetMessage 获取投递记录
+func (s *MailService) GetMessage(id uint) (*Models.MailMessage, error) {
+	var record Models.MailMessage
+	if err := s.db.First(&record, id).Error; err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// ListMessages 查询投递记录
+func (s *MailService) ListMessages(filter MailMessageFilter) ([]Models.MailMessage, int64, error) {
+	query := s.db.Model(&Models.MailMessage{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Recipient != "" {
+		query = query.Where("recipients LIKE ?", "%"+strings.ToLower(filter.Recipient)+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 20
+	}
+	var messages []Models.MailMessage
+	err := query.Order("created_at DESC").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&messages).Error
+	return messages, total, err
+}
+
+// Suppress 将地址加入屏蔽列表，已存在时更新原因和详情
+func (s *MailService) Suppress(email, reason, source, detail string) error {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return ErrMailNoRecipients
+	}
+
+	var suppression Models.MailSuppression
+	err := s.db.Where("email = ?", email).First(&suppression).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.db.Create(&Models.MailSuppression{Email: email, Reason: reason, Source: source, Detail: detail}).Error
+	}
+	if err != nil {
+		return err
+	}
+	return s.db.Model(&suppression).Updates(map[string]interface{}{
+		"reason": reason,
+		"source": source,
+		"detail": detail,
+	}).Error
+}
+
+// Unsuppress 将地址移出屏蔽列表
+func (s *MailService) Unsuppress(email string) error {
+	result := s.db.Where("email = ?", strings.ToLower(strings.TrimSpace(email))).Delete(&Models.MailSuppression{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrMailSuppressionNotFound
+	}
+	return nil
+}
+
+// IsSuppressed 地址是否在屏蔽列表中
+func (s *MailService) IsSuppressed(email string) bool {
+	var count int64
+	s.db.Model(&Models.MailSuppression{}).Where("email = ?", strings.ToLower(strings.TrimSpace(email))).Count(&count)
+	return count > 0
+}
+
+// ListSuppressions 查询屏蔽列表
+func (s *MailService) ListSuppressions(search string, page, limit int) ([]Models.MailSuppression, int64, error) {
+	query := s.db.Model(&Models.MailSuppression{})
+	if search != "" {
+		query = query.Where("email LIKE ?", "%"+strings.ToLower(search)+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	var suppressions []Models.MailSuppression
+	err := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&suppressions).Error
+	return suppressions, total, err
+}
+
+// filterSuppressed 返回不在屏蔽列表中的收件人
+func (s *MailService) filterSuppressed(message *Mail) []string {
+	recipients := message.Recipients()
+	lowered := make([]string, 0, len(recipients))
+	for _, recipient := range recipients {
+		lowered = append(lowered, strings.ToLower(recipient))
+	}
+
+	var suppressed []string
+	s.db.Model(&Models.MailSuppression{}).Where("email IN ?", lowered).Pluck("email", &suppressed)
+	if len(suppressed) == 0 {
+		return recipients
+	}
+
+	blocked := make(map[string]bool, len(suppressed))
+	for _, email := range suppressed {
+		blocked[email] = true
+	}
+	var allowed []string
+	for _, recipient := range recipients {
+		if !blocked[strings.ToLower(recipient)] {
+			allowed = append(allowed, recipient)
+		}
+	}
+	return allowed
+}
+
+func keepMailRecipients(list, allowed []string) []string {
+	var result []string
+	for _, address := range list {
+		for _, candidate := range allowed {
+			if strings.EqualFold(address, candidate) {
+				result = append(result, address)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// mailBounce 服务商回调中的一条退信或投诉
+type mailBounce struct {
+	Email             string
+	Reason            string
+	Detail            string
+	ProviderMessageID string
+}
+
+// HandleBounceWebhook 处理服务商的退信、投诉回调，返回加入屏蔽列表的地址数量
+//
+// 支持 SendGrid 事件回调、Mailgun webhook 和 SES 通过 SNS 推送的通知；软退信不处理。
+func (s *MailService) HandleBounceWebhook(provider string, body []byte) (int, error) {
+	var bounces []mailBounce
+	var err error
+	switch provider {
+	case Config.MailDriverSendGrid:
+		bounces, err = parseSendGridBounces(body)
+	case Config.MailDriverMailgun:
+		bounces, err = parseMailgunBounces(body)
+	case Config.MailDriverSES:
+		bounces, err = parseSESBounces(body)
+	default:
+		return 0, ErrUnsupportedMailProvider
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, bounce := range bounces {
+		if err := s.Suppress(bounce.Email, bounce.Reason, provider, bounce.Detail); err != nil {
+			return count, err
+		}
+		count++
+		if bounce.ProviderMessageID != "" {
+			s.db.Model(&Models.MailMessage{}).
+				Where("provider_message_id = ? AND status = ?", bounce.ProviderMessageID, Models.MailStatusSent).
+				Updates(map[string]interface{}{"status": Models.MailStatusBounced, "last_error": bounce.Detail})
+		}
+	}
+	return count, nil
+}
+
+func parseSendGridBounces(body []byte) ([]mailBounce, error) {
+	var events []struct {
+		Event       string `json:"event"`
+		Email       string `json:"email"`
+		Type        string `json:"type"`
+		Reason      string `json:"reason"`
+		SGMessageID string `json:"sg_message_id"`
+	}
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, fmt.Errorf("解析SendGrid回调失败: %v", err)
+	}
+
+	var bounces []mailBounce
+	for _, event := range events {
+		// sg_message_id 形如 <X-Message-Id>.filterxxx
+		providerID := strings.SplitN(event.SGMessageID, ".", 2)[0]
+		switch {
+		case event.Event == "bounce" && event.Type != "blocked":
+			bounces = append(bounces, mailBounce{Email: event.Email, Reason: Models.MailSuppressionBounce, Detail: event.Reason, ProviderMessageID: providerID})
+		case event.Event == "spamreport":
+			bounces = append(bounces, mailBounce{Email: event.Email, Reason: Models.MailSuppressionComplaint, Detail: "spamreport", ProviderMessageID: providerID})
+		}
+	}
+	return bounces, nil
+}
+
+func parseMailgunBounces(body []byte) ([]mailBounce, error) {
+	var payload struct {
+		EventData struct {
+			Event          string `json:"event"`
+			Severity       string `json:"severity"`
+			Recipient      string `json:"recipient"`
+			DeliveryStatus struct {
+				Message     string `json:"message"`
+				Description string `json:"description"`
+			} `json:"delivery-status"`
+			Message struct {
+				Headers struct {
+					MessageID string `json:"message-id"`
+				} `json:"headers"`
+			} `json:"message"`
+		} `json:"event-data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("解析Mailgun回调失败: %v", err)
+	}
+
+	event := payload.EventData
+	providerID := strings.Trim(event.Message.Headers.MessageID, "<>")
+	switch {
+	case event.Event == "failed" && event.Severity == "permanent":
+		detail := event.DeliveryStatus.Message
+		if detail == "" {
+			detail = event.DeliveryStatus.Description
+		}
+		return []mailBounce{{Email: event.Recipient, Reason: Models.MailSuppressionBounce, Detail: detail, ProviderMessageID: providerID}}, nil
+	case event.Event == "complained":
+		return []mailBounce{{Email: event.Recipient, Reason: Models.MailSuppressionComplaint, Detail: "complained", ProviderMessageID: providerID}}, nil
+	}
+	return nil, nil
+}
+
+func parseSESBounces(body []byte) ([]mailBounce, error) {
+	var envelope struct {
+		Type         string `json:"Type"`
+		Message      string `json:"Message"`
+		SubscribeURL string `json:"SubscribeURL"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("解析SES通知失败: %v", err)
+	}
+	if envelope.Type == "SubscriptionConfirmation" {
+		// 订阅确认需要管理员访问 SubscribeURL，这里只记录
+		log.Printf("收到SES退信通知订阅确认请求: %s", envelope.SubscribeURL)
+		return nil, nil
+	}
+
+	var notification struct {
+		NotificationType string `json:"notificationType"`
+		Bounce           struct {
+			BounceType        string `json:"bounceType"`
+			BouncedRecipients []struct {
+				EmailAddress   string `json:"emailAddress"`
+				DiagnosticCode string `json:"diagnosticCode"`
+			} `json:"bouncedRecipients"`
+		} `json:"bounce"`
+		Complaint struct {
+			ComplainedRecipients []struct {
+				EmailAddress string `json:"emailAddress"`
+			} `json:"complainedRecipients"`
+		} `json:"complaint"`
+		Mail struct {
+			MessageID string `json:"messageId"`
+		} `json:"mail"`
+	}
+	if err := json.Unmarshal([]byte(envelope.Message), &notification); err != nil {
+		return nil, fmt.Errorf("解析SES通知失败: %v", err)
+	}
+
+	var bounces []mailBounce
+	switch notification.NotificationType {
+	case "Bounce":
+		if notification.Bounce.BounceType != "Permanent" {
+			return nil, nil
+		}
+		for _, recipient := range notification.Bounce.BouncedRecipients {
+			bounces = append(bounces, mailBounce{Email: recipient.EmailAddress, Reason: Models.MailSuppressionBounce, Detail: recipient.DiagnosticCode, ProviderMessageID: notification.Mail.MessageID})
+		}
+	case "Complaint":
+		for _, recipient := range notification.Complaint.ComplainedRecipients {
+			bounces = append(bounces, mailBounce{Email: recipient.EmailAddress, Reason: Models.MailSuppressionComplaint, Detail: "complaint", ProviderMessageID: notification.Mail.MessageID})
+		}
+	}
+	return bounces, nil
+}
+
+// newMailMessageID 生成 Message-ID，域名取自发件人地址
+func newMailMessageID(from string) string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 && at < len(from)-1 {
+		domain = from[at+1:]
+	}
+	return hex.EncodeToString(buf) + "@" + domain
+}
+
+var (
+	defaultMailService   *MailService
+	defaultMailServiceMu sync.RWMutex
+)
+
+// SetDefaultMailService 设置全局邮件服务，设置后通知邮件统一走发送队列
+func SetDefaultMailService(service *MailService) {
+	defaultMailServiceMu.Lock()
+	defer defaultMailServiceMu.Unlock()
+	defaultMailService = service
+}
+
+// DefaultMailService 获取全局邮件服务，未设置时返回 nil
+func DefaultMailService() *MailService {
+	defaultMailServiceMu.RLock()
+	defer defaultMailServiceMu.RUnlock()
+	return defaultMailService
+}
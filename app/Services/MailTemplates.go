@@ -0,0 +1,251 @@
+package Services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+)
+
+// MailLayoutNone 不使用布局，只渲染模板的 content 部分
+const MailLayoutNone = "none"
+
+// defaultMailLayout 内置默认布局，模板目录下存在 layouts/default.html 时以文件为准
+const defaultMailLayout = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>{{.Subject}}</title>
+</head>
+<body style="margin:0;padding:0;background:#f5f6f8;font-family:Arial,'Microsoft YaHei',sans-serif;color:#333;">
+<table width="100%" cellpadding="0" cellspacing="0" style="padding:24px 0;">
+<tr><td align="center">
+<table width="600" cellpadding="0" cellspacing="0" style="background:#fff;border-radius:6px;padding:32px;">
+<tr><td>{{.Content}}</td></tr>
+</table>
+<p style="font-size:12px;color:#999;">此邮件由系统自动发送，请勿直接回复。</p>
+</td></tr>
+</table>
+</body>
+</html>`
+
+// ErrMailTemplateNotFound 邮件模板不存在
+var ErrMailTemplateNotFound = errors.New("邮件模板不存在")
+
+// mailLayoutData 布局渲染数据
+type mailLayoutData struct {
+	Subject string
+	Content htmltemplate.HTML
+	Data    map[string]interface{}
+}
+
+// MailTemplateRenderer 邮件模板渲染器
+// 功能说明：
+// 1. 模板文件为 <目录>/<名称>.html，通过 {{define "subject"}} 和 {{define "content"}} 定义主题和正文
+// 2. 正文渲染后套入布局 <目录>/layouts/<布局>.html，布局中用 {{.Content}} 引用正文；layout 为 none 时不套布局
+// 3. 存在 <名称>.txt 时作为纯文本版本渲染，否则从HTML中提取纯文本
+// 4. 解析后的模板会被缓存，修改模板文件后需要重启服务或调用 Reset
+type MailTemplateRenderer struct {
+	path      string
+	mu        sync.RWMutex
+	templates map[string]*htmltemplate.Template
+	texts     map[string]*texttemplate.Template
+	layouts   map[string]*htmltemplate.Template
+}
+
+// NewMailTemplateRenderer 创建邮件模板渲染器
+func NewMailTemplateRenderer(path string) *MailTemplateRenderer {
+	return &MailTemplateRenderer{
+		path:      path,
+		templates: make(map[string]*htmltemplate.Template),
+		texts:     make(map[string]*texttemplate.Template),
+		layouts:   make(map[string]*htmltemplate.Template),
+	}
+}
+
+// Reset 清空模板缓存
+func (r *MailTemplateRenderer) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.templates = make(map[string]*htmltemplate.Template)
+	r.texts = make(map[string]*texttemplate.Template)
+	r.layouts = make(map[string]*htmltemplate.Template)
+}
+
+// Render 渲染模板，返回主题、HTML正文和纯文本正文
+func (r *MailTemplateRenderer) Render(name, layout string, data map[string]interface{}) (string, string, string, error) {
+	tmpl, err := r.template(name)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	var subject bytes.Buffer
+	if tmpl.Lookup("subject") != nil {
+		if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+			return "", "", "", fmt.Errorf("渲染邮件主题失败: %v", err)
+		}
+	}
+
+	var content bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&content, "content", data); err != nil {
+		return "", "", "", fmt.Errorf("渲染邮件正文失败: %v", err)
+	}
+	subjectText := strings.TrimSpace(html.UnescapeString(subject.String()))
+
+	body := content.String()
+	if layout != MailLayoutNone {
+		layoutTmpl, err := r.layout(layout)
+		if err != nil {
+			return "", "", "", err
+		}
+		var wrapped bytes.Buffer
+		err = layoutTmpl.Execute(&wrapped, mailLayoutData{
+			Subject: subjectText,
+			Content: htmltemplate.HTML(body),
+			Data:    data,
+		})
+		if err != nil {
+			return "", "", "", fmt.Errorf("渲染邮件布局失败: %v", err)
+		}
+		body = wrapped.String()
+	}
+
+	text := ""
+	if textTmpl, err := r.textTemplate(name); err != nil {
+		return "", "", "", err
+	} else if textTmpl != nil {
+		var buf bytes.Buffer
+		if err := textTmpl.Execute(&buf, data); err != nil {
+			return "", "", "", fmt.Errorf("渲染纯文本邮件失败: %v", err)
+		}
+		text = strings.TrimSpace(buf.String())
+	} else {
+		text = htmlToText(content.String())
+	}
+
+	return subjectText, body, text, nil
+}
+
+func (r *MailTemplateRenderer) template(name string) (*htmltemplate.Template, error) {
+	if !validMailTemplateName(name) {
+		return nil, fmt.Errorf("%w: %s", ErrMailTemplateNotFound, name)
+	}
+
+	r.mu.RLock()
+	tmpl, ok := r.templates[name]
+	r.mu.RUnlock()
+	if ok {
+		return tmpl, nil
+	}
+
+	file := filepath.Join(r.path, name+".html")
+	if _, err := os.Stat(file); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrMailTemplateNotFound, name)
+	}
+	tmpl, err := htmltemplate.ParseFiles(file)
+	if err != nil {
+		return nil, fmt.Errorf("解析邮件模板失败: %v", err)
+	}
+	if tmpl.Lookup("content") == nil {
+		return nil, fmt.Errorf("邮件模板 %s 缺少 content 定义", name)
+	}
+
+	r.mu.Lock()
+	r.templates[name] = tmpl
+	r.mu.Unlock()
+	return tmpl, nil
+}
+
+// textTemplate 加载纯文本模板，不存在时返回 nil
+func (r *MailTemplateRenderer) textTemplate(name string) (*texttemplate.Template, error) {
+	r.mu.RLock()
+	tmpl, ok := r.texts[name]
+	r.mu.RUnlock()
+	if ok {
+		return tmpl, nil
+	}
+
+	file := filepath.Join(r.path, name+".txt")
+	if _, err := os.Stat(file); err == nil {
+		tmpl, err = texttemplate.ParseFiles(file)
+		if err != nil {
+			return nil, fmt.Errorf("解析纯文本邮件模板失败: %v", err)
+		}
+	}
+
+	r.mu.Lock()
+	r.texts[name] = tmpl
+	r.mu.Unlock()
+	return tmpl, nil
+}
+
+func (r *MailTemplateRenderer) layout(name string) (*htmltemplate.Template, error) {
+	if name == "" {
+		name = "default"
+	}
+	if !validMailTemplateName(name) {
+		return nil, fmt.Errorf("%w: layouts/%s", ErrMailTemplateNotFound, name)
+	}
+
+	r.mu.RLock()
+	tmpl, ok := r.layouts[name]
+	r.mu.RUnlock()
+	if ok {
+		return tmpl, nil
+	}
+
+	var err error
+	file := filepath.Join(r.path, "layouts", name+".html")
+	if _, statErr := os.Stat(file); statErr == nil {
+		tmpl, err = htmltemplate.ParseFiles(file)
+	} else if name == "default" {
+		tmpl, err = htmltemplate.New("default").Parse(defaultMailLayout)
+	} else {
+		return nil, fmt.Errorf("%w: layouts/%s", ErrMailTemplateNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析邮件布局失败: %v", err)
+	}
+
+	r.mu.Lock()
+	r.layouts[name] = tmpl
+	r.mu.Unlock()
+	return tmpl, nil
+}
+
+var mailTemplateNamePattern = regexp.MustCompile(`^[A-Za-z0-9_\-]+(/[A-Za-z0-9_\-]+)*$`)
+
+// validMailTemplateName 模板名只允许字母数字、下划线、短横线和子目录，防止路径穿越
+func validMailTemplateName(name string) bool {
+	return mailTemplateNamePattern.MatchString(name)
+}
+
+var (
+	htmlBlockPattern      = regexp.MustCompile(`(?i)<\s*(br|/p|/div|/h[1-6]|/li|/tr)\s*/?>`)
+	htmlTagPattern        = regexp.MustCompile(`<[^>]*>`)
+	htmlStripPattern      = regexp.MustCompile(`(?is)<(style|script)[^>]*>.*?</(style|script)>`)
+	htmlBlankLinesPattern = regexp.MustCompile(`\n\s*\n+`)
+)
+
+// htmlToText 从HTML中提取纯文本，用于没有纯文本模板的邮件
+func htmlToText(content string) string {
+	content = htmlStripPattern.ReplaceAllString(content, "")
+	content = htmlBlockPattern.ReplaceAllString(content, "\n")
+	content = htmlTagPattern.ReplaceAllString(content, "")
+	content = html.UnescapeString(content)
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	content = strings.Join(lines, "\n")
+	return strings.TrimSpace(htmlBlankLinesPattern.ReplaceAllString(content, "\n\n"))
+}
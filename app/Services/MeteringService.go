@@ -0,0 +1,548 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 用量指标
+const (
+	UsageMetricAPICalls          = "api_calls"          // 已认证的API调用次数
+	UsageMetricStorageBytes      = "storage_bytes"      // 上传到存储的字节数
+	UsageMetricMetricsIngested   = "metrics_ingested"   // 推送接收的指标采样数
+	UsageMetricNotificationsSent = "notifications_sent" // 发送的邮件和短信通知数
+)
+
+// UsageMetrics 全部用量指标
+var UsageMetrics = []string{UsageMetricAPICalls, UsageMetricStorageBytes, UsageMetricMetricsIngested, UsageMetricNotificationsSent}
+
+// usageDateLayout 用量记录的日期格式
+const usageDateLayout = "2006-01-02"
+
+var (
+	// ErrUnknownUsageMetric 不支持的用量指标
+	ErrUnknownUsageMetric = errors.New("不支持的用量指标")
+	// ErrInvalidUsageQuota 限额或查询参数无效
+	ErrInvalidUsageQuota = errors.New("用量参数无效")
+)
+
+// UsageQuotaError 租户在当前周期的用量超过限额时返回该错误
+type UsageQuotaError struct {
+	Tenant  string
+	Metric  string
+	Limit   int64
+	Used    int64
+	ResetAt time.Time // 当前周期结束时间，用量在此之后重新计算
+}
+
+func (e *UsageQuotaError) Error() string {
+	return fmt.Sprintf("%s 已超出 %s 用量限额（已用 %d，限额 %d），将于 %s 重置",
+		e.Tenant, e.Metric, e.Used, e.Limit, e.ResetAt.Format(time.RFC3339))
+}
+
+// UsageSummary 租户当前周期某项用量
+type UsageSummary struct {
+	Metric      string    `json:"metric"`
+	Used        int64     `json:"used"`
+	Limit       int64     `json:"limit"`     // 0表示不限制
+	Remaining   int64     `json:"remaining"` // 不限制时为-1
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+}
+
+// UsageFilter 用量记录查询条件，日期为UTC日期，包含 From 和 To 当天
+type UsageFilter struct {
+	Tenant string
+	Metric string
+	From   time.Time
+	To     time.Time
+}
+
+// UsageQuotaProvider 按租户提供默认限额，如订阅套餐中的限额
+//
+// ok 为 false 时使用配置中的默认限额，租户单独设置的限额优先于提供的限额。
+type UsageQuotaProvider interface {
+	TenantQuota(tenant, metric string) (limit int64, ok bool, err error)
+}
+
+// usageCounter 租户某项用量在当前周期的计数
+type usageCounter struct {
+	period  string           // 周期起始日期，周期变化时重新从数据库加载
+	used    int64            // 当前周期用量，包括未写入数据库的计数
+	limit   int64            // 当前限额
+	pending map[string]int64 // 日期 -> 未写入数据库的计数
+}
+
+// MeteringService 用量计量服务
+// 功能说明：
+// 1. 按租户统计API调用、上传字节、推送指标和通知发送用量，租户为用户（user:<ID>）或指标推送来源（source:<名称>）
+// 2. 计数先在内存中累计，按 FlushInterval 批量写入每天一条的用量记录，停止时写入剩余计数
+// 3. 按周期（自然日或自然月，UTC）检查限额，优先级为租户单独设置的限额、UsageQuotaProvider 提供的限额、配置中的默认限额
+// 4. 用量记录可按租户、指标和日期范围查询或导出为CSV，用于计费
+//
+// 限额检查使用本实例加载的用量和本实例的计数，多实例部署时限额可能被少量超出。
+type MeteringService struct {
+	db     *gorm.DB
+	config *Config.MeteringConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	counters map[string]*usageCounter // 租户|指标 -> 计数
+	quotas   UsageQuotaProvider
+
+	flushMu sync.Mutex
+}
+
+// NewMeteringService 创建用量计量服务
+//
+// config 为 nil 时使用全局配置，全局配置未加载时使用默认值（按月、不限额）。
+func NewMeteringService(db *gorm.DB, config *Config.MeteringConfig) *MeteringService {
+	if config == nil {
+		if config = Config.GetMeteringConfig(); config == nil {
+			config = &Config.MeteringConfig{
+				Enabled:       true,
+				FlushInterval: 10 * time.Second,
+				Period:        Config.MeteringPeriodMonth,
+			}
+		}
+	}
+	return &MeteringService{
+		db:       db,
+		config:   config,
+		now:      time.Now,
+		counters: make(map[string]*usageCounter),
+	}
+}
+
+// SetClock 设置当前时间函数，用于测试周期切换
+func (s *MeteringService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// SetQuotaProvider 设置按租户提供默认限额的来源
+func (s *MeteringService) SetQuotaProvider(provider UsageQuotaProvider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quotas = provider
+	for _, counter := range s.counters {
+		counter.limit = -1
+	}
+}
+
+// ForgetLimits 租户的限额来源变化后调用，下次检查时重新加载全部指标的限额，tenant 为空时对全部租户生效
+func (s *MeteringService) ForgetLimits(tenant string) {
+	if tenant == "" {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, counter := range s.counters {
+			counter.limit = -1
+		}
+		return
+	}
+	for _, metric := range UsageMetrics {
+		s.forgetLimit(tenant, metric)
+	}
+}
+
+// UserTenant 用户对应的租户
+func UserTenant(userID uint) string {
+	return "user:" + strconv.FormatUint(uint64(userID), 10)
+}
+
+// ParseUserTenant 解析用户租户中的用户ID，不是用户租户时返回 false
+func ParseUserTenant(tenant string) (uint, bool) {
+	id, err := strconv.ParseUint(strings.TrimPrefix(tenant, "user:"), 10, 32)
+	if err != nil || id == 0 || !strings.HasPrefix(tenant, "user:") {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// SourceTenant 指标推送来源对应的租户
+func SourceTenant(source string) string {
+	return "source:" + source
+}
+
+// Record 记录用量，不检查限额
+func (s *MeteringService) Record(tenant, metric string, quantity int64) {
+	if quantity <= 0 {
+		return
+	}
+	now := s.now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counter, err := s.counter(tenant, metric, now)
+	if err != nil {
+		log.Printf("加载用量失败，计数仍会写入: tenant=%s, metric=%s, error=%v", tenant, metric, err)
+	}
+	s.add(counter, now, quantity)
+}
+
+// Check 检查增加 quantity 后是否超过限额，不记录用量，超过时返回 *UsageQuotaError
+//
+// 加载用量或限额失败时不阻断请求，避免数据库故障导致所有请求被拒绝。
+func (s *MeteringService) Check(tenant, metric string, quantity int64) error {
+	now := s.now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counter, err := s.counter(tenant, metric, now)
+	if err != nil {
+		log.Printf("加载用量失败，跳过限额检查: tenant=%s, metric=%s, error=%v", tenant, metric, err)
+		return nil
+	}
+	return s.exceeded(tenant, metric, counter, quantity, now)
+}
+
+// Reserve 检查限额并记录用量，超过限额时不记录并返回 *UsageQuotaError
+func (s *MeteringService) Reserve(tenant, metric string, quantity int64) error {
+	now := s.now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counter, err := s.counter(tenant, metric, now)
+	if err != nil {
+		log.Printf("加载用量失败，跳过限额检查: tenant=%s, metric=%s, error=%v", tenant, metric, err)
+	} else if err := s.exceeded(tenant, metric, counter, quantity, now); err != nil {
+		return err
+	}
+	s.add(counter, now, quantity)
+	return nil
+}
+
+// Summary 获取租户当前周期的全部用量和限额
+func (s *MeteringService) Summary(tenant string) ([]UsageSummary, error) {
+	now := s.now().UTC()
+	start, end := s.period(now)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	summaries := make([]UsageSummary, 0, len(UsageMetrics))
+	for _, metric := range UsageMetrics {
+		counter, err := s.counter(tenant, metric, now)
+		if err != nil {
+			return nil, err
+		}
+		remaining := int64(-1)
+		if counter.limit > 0 {
+			remaining = max(counter.limit-counter.used, 0)
+		}
+		summaries = append(summaries, UsageSummary{
+			Metric:      metric,
+			Used:        counter.used,
+			Limit:       counter.limit,
+			Remaining:   remaining,
+			PeriodStart: start,
+			PeriodEnd:   end,
+		})
+	}
+	return summaries, nil
+}
+
+// SetQuota 为租户单独设置限额，limit 为0表示不限制
+func (s *MeteringService) SetQuota(tenant, metric string, limit int64, updatedBy uint) (*Models.UsageQuota, error) {
+	if !slices.Contains(UsageMetrics, metric) {
+		return nil, ErrUnknownUsageMetric
+	}
+	if strings.TrimSpace(tenant) == "" || limit < 0 {
+		return nil, fmt.Errorf("%w：租户不能为空，限额不能为负数", ErrInvalidUsageQuota)
+	}
+	quota := &Models.UsageQuota{Tenant: tenant, Metric: metric, Limit: limit, UpdatedBy: updatedBy}
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant"}, {Name: "metric"}},
+		DoUpdates: clause.AssignmentColumns([]string{"quota_limit", "updated_by", "updated_at"}),
+	}).Create(quota).Error
+	if err != nil {
+		return nil, err
+	}
+	s.forgetLimit(tenant, metric)
+	return quota, nil
+}
+
+// DeleteQuota 删除租户单独设置的限额，恢复使用默认限额
+func (s *MeteringService) DeleteQuota(tenant, metric string) error {
+	if !slices.Contains(UsageMetrics, metric) {
+		return ErrUnknownUsageMetric
+	}
+	if err := s.db.Where("tenant = ? AND metric = ?", tenant, metric).Delete(&Models.UsageQuota{}).Error; err != nil {
+		return err
+	}
+	s.forgetLimit(tenant, metric)
+	return nil
+}
+
+// Quotas 获取单独设置的限额，tenant 为空时返回全部租户的限额
+func (s *MeteringService) Quotas(tenant string) ([]Models.UsageQuota, error) {
+	query := s.db.Order("tenant ASC, metric ASC")
+	if tenant != "" {
+		query = query.Where("tenant = ?", tenant)
+	}
+	var quotas []Models.UsageQuota
+	if err := query.Find(&quotas).Error; err != nil {
+		return nil, err
+	}
+	return quotas, nil
+}
+
+// Records 查询用量记录，查询前先写入内存中的计数
+func (s *MeteringService) Records(filter UsageFilter) ([]Models.UsageRecord, error) {
+	if filter.Metric != "" && !slices.Contains(UsageMetrics, filter.Metric) {
+		return nil, ErrUnknownUsageMetric
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return nil, fmt.Errorf("%w：结束日期不能早于开始日期", ErrInvalidUsageQuota)
+	}
+	if err := s.Flush(); err != nil {
+		return nil, err
+	}
+
+	query := s.db.Order("date ASC, tenant ASC, metric ASC")
+	if filter.Tenant != "" {
+		query = query.Where("tenant = ?", filter.Tenant)
+	}
+	if filter.Metric != "" {
+		query = query.Where("metric = ?", filter.Metric)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("date >= ?", filter.From.UTC().Format(usageDateLayout))
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("date <= ?", filter.To.UTC().Format(usageDateLayout))
+	}
+	var records []Models.UsageRecord
+	if err := query.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// ExportCSV 按查询条件导出用量记录，列为 date、tenant、metric、quantity
+func (s *MeteringService) ExportCSV(w io.Writer, filter UsageFilter) error {
+	records, err := s.Records(filter)
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"date", "tenant", "metric", "quantity"}); err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := writer.Write([]string{record.Date, record.Tenant, record.Metric, strconv.FormatInt(record.Quantity, 10)}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Start 按 FlushInterval 定期写入内存中的计数，ctx 取消时写入剩余计数后退出
+func (s *MeteringService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.config.FlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				if err := s.Flush(); err != nil {
+					log.Printf("写入用量记录失败: %v", err)
+				}
+				return
+			case <-ticker.C:
+				if err := s.Flush(); err != nil {
+					log.Printf("写入用量记录失败: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Flush 将内存中的计数累加到每天的用量记录，写入失败的计数保留到下次写入
+func (s *MeteringService) Flush() error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	type pendingUsage struct {
+		tenant, metric, date string
+		quantity             int64
+	}
+	s.mu.Lock()
+	var batch []pendingUsage
+	for key, counter := range s.counters {
+		tenant, metric, _ := strings.Cut(key, "|")
+		for date, quantity := range counter.pending {
+			batch = append(batch, pendingUsage{tenant: tenant, metric: metric, date: date, quantity: quantity})
+		}
+		counter.pending = make(map[string]int64)
+	}
+	s.mu.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, usage := range batch {
+			record := &Models.UsageRecord{Tenant: usage.tenant, Metric: usage.metric, Date: usage.date, Quantity: usage.quantity}
+			err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "tenant"}, {Name: "metric"}, {Name: "date"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"quantity":   gorm.Expr("usage_records.quantity + ?", usage.quantity),
+					"updated_at": s.now(),
+				}),
+			}).Create(record).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// 写入失败时放回内存，下次写入时重试
+		s.mu.Lock()
+		for _, usage := range batch {
+			counter := s.counters[usage.tenant+"|"+usage.metric]
+			if counter == nil {
+				counter = &usageCounter{pending: make(map[string]int64)}
+				s.counters[usage.tenant+"|"+usage.metric] = counter
+			}
+			counter.pending[usage.date] += usage.quantity
+		}
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// period 当前周期的起止时间
+func (s *MeteringService) period(now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	if s.config.Period == Config.MeteringPeriodDay {
+		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 0, 1)
+	}
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// counter 获取租户某项用量在当前周期的计数，首次访问或周期变化时从数据库加载用量和限额，调用方需持有 s.mu
+func (s *MeteringService) counter(tenant, metric string, now time.Time) (*usageCounter, error) {
+	key := tenant + "|" + metric
+	counter := s.counters[key]
+	if counter == nil {
+		counter = &usageCounter{pending: make(map[string]int64), limit: -1}
+		s.counters[key] = counter
+	}
+	start, end := s.period(now)
+	period := start.Format(usageDateLayout)
+	if counter.period == period && counter.limit >= 0 {
+		return counter, nil
+	}
+
+	if counter.period != period {
+		var used int64
+		err := s.db.Model(&Models.UsageRecord{}).
+			Where("tenant = ? AND metric = ? AND date >= ? AND date < ?", tenant, metric, period, end.Format(usageDateLayout)).
+			Select("COALESCE(SUM(quantity), 0)").Scan(&used).Error
+		if err != nil {
+			return counter, err
+		}
+		for date, quantity := range counter.pending {
+			if date >= period {
+				used += quantity
+			}
+		}
+		counter.period = period
+		counter.used = used
+	}
+
+	limit := s.defaultLimit(metric)
+	if s.quotas != nil {
+		provided, ok, err := s.quotas.TenantQuota(tenant, metric)
+		if err != nil {
+			return counter, err
+		}
+		if ok {
+			limit = provided
+		}
+	}
+	var quota Models.UsageQuota
+	err := s.db.Where("tenant = ? AND metric = ?", tenant, metric).First(&quota).Error
+	switch {
+	case err == nil:
+		limit = quota.Limit
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return counter, err
+	}
+	counter.limit = limit
+	return counter, nil
+}
+
+// exceeded 增加 quantity 后超过限额时返回 *UsageQuotaError
+func (s *MeteringService) exceeded(tenant, metric string, counter *usageCounter, quantity int64, now time.Time) error {
+	if counter.limit <= 0 || counter.used+quantity <= counter.limit {
+		return nil
+	}
+	_, end := s.period(now)
+	return &UsageQuotaError{Tenant: tenant, Metric: metric, Limit: counter.limit, Used: counter.used, ResetAt: end}
+}
+
+// add 累加计数，调用方需持有 s.mu
+func (s *MeteringService) add(counter *usageCounter, now time.Time, quantity int64) {
+	counter.used += quantity
+	counter.pending[now.Format(usageDateLayout)] += quantity
+}
+
+// forgetLimit 限额变化后下次检查时重新加载
+func (s *MeteringService) forgetLimit(tenant, metric string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if counter := s.counters[tenant+"|"+metric]; counter != nil {
+		counter.limit = -1
+	}
+}
+
+// defaultLimit 配置中的默认限额
+func (s *MeteringService) defaultLimit(metric string) int64 {
+	switch metric {
+	case UsageMetricAPICalls:
+		return s.config.QuotaAPICalls
+	case UsageMetricStorageBytes:
+		return s.config.QuotaStorageBytes
+	case UsageMetricMetricsIngested:
+		return s.config.QuotaMetricsIngested
+	case UsageMetricNotificationsSent:
+		return s.config.QuotaNotificationsSent
+	}
+	return 0
+}
+
+var (
+	defaultMeteringService   *MeteringService
+	defaultMeteringServiceMu sync.RWMutex
+)
+
+// SetDefaultMeteringService 设置全局用量计量服务
+func SetDefaultMeteringService(service *MeteringService) {
+	defaultMeteringServiceMu.Lock()
+	defer defaultMeteringServiceMu.Unlock()
+	defaultMeteringService = service
+}
+
+// DefaultMeteringService 获取全局用量计量服务，未启用用量计量时返回 nil
+//
+// 计数在服务内存中累计，不会为调用方临时创建服务，避免计数丢失。
+func DefaultMeteringService() *MeteringService {
+	defaultMeteringServiceMu.RLock()
+	defer defaultMeteringServiceMu.RUnlock()
+	return defaultMeteringService
+}
@@ -0,0 +1,344 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// metricWriteBehindTimeout 写入Redis队列的超时，超时后退回内存队列，避免阻塞调用方
+const metricWriteBehindTimeout = 500 * time.Millisecond
+
+// MetricSampleSink 批量保存指标采样的存储，MetricHistoryService 实现该接口
+type MetricSampleSink interface {
+	RecordBatch(samples []Models.MetricSample) error
+}
+
+// MetricBatchWriterStats 批量写入统计，计数从启动开始累计
+type MetricBatchWriterStats struct {
+	Queued      int       `json:"queued"`       // 内存队列中待写入的采样数
+	Written     int64     `json:"written"`      // 已写入的采样数
+	Batches     int64     `json:"batches"`      // 成功写入的批次数
+	Retries     int64     `json:"retries"`      // 重试次数
+	Failures    int64     `json:"failures"`     // 重试耗尽仍失败的批次数
+	Dropped     int64     `json:"dropped"`      // 队列已满或无法解析被丢弃的采样数
+	WriteBehind bool      `json:"write_behind"` // 是否启用Redis写后缓冲
+	LastFlushAt time.Time `json:"last_flush_at,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// MetricBatchWriter 指标采样批量写入器
+// 功能说明：
+// 1. Write 只把采样放入缓冲队列，不访问数据库；达到批量大小或刷新间隔时由后台协程批量插入
+// 2. 单批写入失败时按退避时间重试，重试耗尽后放回队列等待下次刷新，队列已满时丢弃并计数
+// 3. 启用Redis写后缓冲时采样先写入Redis列表，后台按批读取写入数据库，成功后才从列表移除；Redis不可用时退回内存队列
+// 4. 每次刷新后向监控核心上报 metric_write_queue_size、metric_write_samples_total、metric_write_failures_total、metric_write_dropped_total
+type MetricBatchWriter struct {
+	sink          MetricSampleSink
+	size          int
+	maxQueue      int
+	maxRetries    int
+	flushInterval time.Duration
+	retryBackoff  time.Duration
+	core          *MonitoringCore
+
+	redis    redis.UniversalClient
+	redisKey string
+
+	mu     sync.Mutex
+	buffer []Models.MetricSample
+	stats  MetricBatchWriterStats
+
+	// flushMu 保证同一时间只有一次刷新，避免重复写入Redis队列中的同一批采样
+	flushMu sync.Mutex
+	notify  chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	started bool
+	once    sync.Once
+}
+
+// NewMetricBatchWriter 创建指标批量写入器，参数使用监控存储配置（MONITORING_STORAGE_BATCH_*）
+func NewMetricBatchWriter(sink MetricSampleSink, config *Config.MonitoringConfig) *MetricBatchWriter {
+	if config == nil {
+		config = &Config.MonitoringConfig{}
+		config.SetDefaults()
+		if globalConfig := Config.GetConfig(); globalConfig != nil {
+			config = &globalConfig.Monitoring
+		}
+	}
+
+	batch := config.StorageConfig.Batch
+	writer := &MetricBatchWriter{
+		sink:          sink,
+		size:          batch.Size,
+		maxQueue:      batch.MaxQueue,
+		maxRetries:    batch.MaxRetries,
+		flushInterval: batch.FlushInterval,
+		retryBackoff:  batch.RetryBackoff,
+		core:          DefaultMonitoringCore(),
+		notify:        make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	if writer.size <= 0 {
+		writer.size = 500
+	}
+	if writer.maxQueue < writer.size {
+		writer.maxQueue = writer.size
+	}
+	if writer.flushInterval <= 0 {
+		writer.flushInterval = 10 * time.Second
+	}
+	return writer
+}
+
+// SetMonitoringCore 设置上报写入统计的监控核心
+func (w *MetricBatchWriter) SetMonitoringCore(core *MonitoringCore) {
+	w.core = core
+}
+
+// SetRedisWriteBehind 启用Redis写后缓冲，采样先写入 key 对应的列表
+func (w *MetricBatchWriter) SetRedisWriteBehind(client redis.UniversalClient, key string) {
+	w.redis = client
+	w.redisKey = key
+	w.mu.Lock()
+	w.stats.WriteBehind = client != nil
+	w.mu.Unlock()
+}
+
+// Write 把采样放入写入队列，不等待写入数据库
+func (w *MetricBatchWriter) Write(samples []Models.MetricSample) {
+	if len(samples) == 0 {
+		return
+	}
+	if w.redis != nil {
+		length, err := w.pushRedis(samples)
+		if err == nil {
+			w.signal(length >= int64(w.size))
+			return
+		}
+		log.Printf("写入指标Redis队列失败，改用内存队列: %v", err)
+	}
+
+	w.mu.Lock()
+	if space := w.maxQueue - len(w.buffer); len(samples) > space {
+		w.stats.Dropped += int64(len(samples) - space)
+		samples = samples[:space]
+	}
+	w.buffer = append(w.buffer, samples...)
+	full := len(w.buffer) >= w.size
+	w.mu.Unlock()
+
+	w.signal(full)
+}
+
+// signal 队列达到批量大小时通知后台协程立即刷新
+func (w *MetricBatchWriter) signal(full bool) {
+	if !full {
+		return
+	}
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// pushRedis 以JSON追加到Redis列表，返回追加后的列表长度
+func (w *MetricBatchWriter) pushRedis(samples []Models.MetricSample) (int64, error) {
+	values := make([]interface{}, 0, len(samples))
+	for _, sample := range samples {
+		data, err := json.Marshal(sample)
+		if err != nil {
+			return 0, err
+		}
+		values = append(values, data)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), metricWriteBehindTimeout)
+	defer cancel()
+	return w.redis.RPush(ctx, w.redisKey, values...).Result()
+}
+
+// Flush 写入内存队列和Redis队列中的全部采样，返回最后一次写入失败的错误
+func (w *MetricBatchWriter) Flush() error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	var lastErr error
+	for {
+		w.mu.Lock()
+		if len(w.buffer) == 0 {
+			w.mu.Unlock()
+			break
+		}
+		n := min(len(w.buffer), w.size)
+		batch := append([]Models.MetricSample(nil), w.buffer[:n]...)
+		w.buffer = w.buffer[n:]
+		w.mu.Unlock()
+
+		if err := w.writeWithRetry(batch); err != nil {
+			w.requeue(batch)
+			lastErr = err
+			break
+		}
+	}
+
+	if w.redis != nil {
+		if err := w.flushRedis(); err != nil {
+			lastErr = err
+		}
+	}
+
+	w.mu.Lock()
+	w.stats.LastFlushAt = time.Now()
+	if lastErr != nil {
+		w.stats.LastError = lastErr.Error()
+	} else {
+		w.stats.LastError = ""
+	}
+	w.mu.Unlock()
+	w.publishStats()
+	return lastErr
+}
+
+// flushRedis 按批读取Redis列表写入数据库，写入成功后才从列表移除，保证至少写入一次
+func (w *MetricBatchWriter) flushRedis() error {
+	ctx := context.Background()
+	for {
+		items, err := w.redis.LRange(ctx, w.redisKey, 0, int64(w.size-1)).Result()
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+
+		batch := make([]Models.MetricSample, 0, len(items))
+		for _, item := range items {
+			var sample Models.MetricSample
+			if err := json.Unmarshal([]byte(item), &sample); err != nil {
+				w.mu.Lock()
+				w.stats.Dropped++
+				w.mu.Unlock()
+				continue
+			}
+			batch = append(batch, sample)
+		}
+		if err := w.writeWithRetry(batch); err != nil {
+			return err
+		}
+		if err := w.redis.LTrim(ctx, w.redisKey, int64(len(items)), -1).Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// writeWithRetry 写入一批采样，失败时按指数退避重试
+func (w *MetricBatchWriter) writeWithRetry(batch []Models.MetricSample) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	var err error
+	for attempt := 0; attempt <= w.maxRetries; attempt++ {
+		if attempt > 0 {
+			w.mu.Lock()
+			w.stats.Retries++
+			w.mu.Unlock()
+			time.Sleep(w.retryBackoff << (attempt - 1))
+		}
+		if err = w.sink.RecordBatch(batch); err == nil {
+			w.mu.Lock()
+			w.stats.Written += int64(len(batch))
+			w.stats.Batches++
+			w.mu.Unlock()
+			return nil
+		}
+	}
+
+	w.mu.Lock()
+	w.stats.Failures++
+	w.mu.Unlock()
+	log.Printf("批量写入指标失败: samples=%d, error=%v", len(batch), err)
+	return err
+}
+
+// requeue 把写入失败的批次放回队列头部，超出队列上限的部分丢弃
+func (w *MetricBatchWriter) requeue(batch []Models.MetricSample) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buffer = append(batch, w.buffer...)
+	if overflow := len(w.buffer) - w.maxQueue; overflow > 0 {
+		w.stats.Dropped += int64(overflow)
+		w.buffer = w.buffer[:w.maxQueue]
+	}
+}
+
+// publishStats 向监控核心上报写入统计
+func (w *MetricBatchWriter) publishStats() {
+	if w.core == nil {
+		return
+	}
+	stats := w.Stats()
+	w.core.Observe("metric_write_queue_size", float64(stats.Queued))
+	w.core.Observe("metric_write_samples_total", float64(stats.Written))
+	w.core.Observe("metric_write_failures_total", float64(stats.Failures))
+	w.core.Observe("metric_write_dropped_total", float64(stats.Dropped))
+}
+
+// Stats 返回写入统计
+func (w *MetricBatchWriter) Stats() MetricBatchWriterStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	stats := w.stats
+	stats.Queued = len(w.buffer)
+	return stats
+}
+
+// Start 启动后台刷新协程，按刷新间隔或队列达到批量大小时写入
+func (w *MetricBatchWriter) Start() {
+	w.mu.Lock()
+	if w.started {
+		w.mu.Unlock()
+		return
+	}
+	w.started = true
+	w.mu.Unlock()
+
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(w.flushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+			case <-w.notify:
+			}
+			w.Flush()
+		}
+	}()
+}
+
+// Close 停止后台协程并写入剩余采样
+func (w *MetricBatchWriter) Close() error {
+	w.mu.Lock()
+	started := w.started
+	w.mu.Unlock()
+
+	w.once.Do(func() {
+		close(w.stop)
+	})
+	if started {
+		<-w.done
+	}
+	return w.Flush()
+}
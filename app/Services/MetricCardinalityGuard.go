@@ -0,0 +1,290 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidMetricLabels 指标标签不合法
+	ErrInvalidMetricLabels = errors.New("指标标签不合法")
+	// ErrMetricCardinalityExceeded 指标序列数超过上限
+	ErrMetricCardinalityExceeded = errors.New("指标序列数超过上限")
+)
+
+// MetricCardinalityOverflowLabel 超过上限的标签组合合并后使用的标签，值为 "true"
+const MetricCardinalityOverflowLabel = "overflow"
+
+// 指标基数告警类型
+const (
+	MetricCardinalityAlertWarning  = "metric_cardinality_warning"
+	MetricCardinalityAlertExceeded = "metric_cardinality_exceeded"
+)
+
+// MetricCardinalityStat 单个指标的基数统计
+type MetricCardinalityStat struct {
+	Metric         string     `json:"metric"`
+	Series         int        `json:"series"`           // 最近 SeriesTTL 内出现的标签组合数
+	Limit          int        `json:"limit"`            // 序列数上限
+	Usage          float64    `json:"usage"`            // 序列数占上限的比例
+	Rejected       int64      `json:"rejected"`         // 超过上限被拒绝的采样数
+	Aggregated     int64      `json:"aggregated"`       // 超过上限被合并的采样数
+	InvalidLabels  int64      `json:"invalid_labels"`   // 标签不合法被拒绝的采样数
+	LastOverflowAt *time.Time `json:"last_overflow_at"` // 最近一次超过上限的时间
+}
+
+// metricCardinality 单个指标的序列跟踪状态
+type metricCardinality struct {
+	series         map[string]time.Time // 标签组合 -> 最近出现时间
+	rejected       int64
+	aggregated     int64
+	invalidLabels  int64
+	lastOverflowAt time.Time
+	lastAlerts     map[string]time.Time
+}
+
+// MetricCardinalityGuard 指标基数保护
+// 功能说明：
+// 1. 校验标签数量、标签名称和标签值长度，不合法的标签返回 ErrInvalidMetricLabels
+// 2. 按指标名称跟踪最近 SeriesTTL 内出现的标签组合，MONITORING_CARDINALITY_LIMITS 可为单个指标设置上限
+// 3. 新标签组合超过上限时按配置拒绝，或合并为 overflow="true" 序列，已跟踪的标签组合不受影响
+// 4. 序列数达到上限的 WarnRatio 时产生告警，超过上限时产生高级别告警，同一指标同类告警按 AlertCooldown 限频
+// 5. 按序列占用比例列出基数最高的指标，供管理员定位标签使用不当的指标
+//
+// 未启用或未创建时所有标签原样通过
+type MetricCardinalityGuard struct {
+	config *Config.MonitoringConfig
+	limits map[string]int
+
+	mu           sync.Mutex
+	metrics      map[string]*metricCardinality
+	alertHandler func(MonitoringAlert)
+	now          func() time.Time
+}
+
+// NewMetricCardinalityGuard 创建指标基数保护
+func NewMetricCardinalityGuard(config *Config.MonitoringConfig) *MetricCardinalityGuard {
+	if config == nil {
+		config = &Config.MonitoringConfig{}
+		config.SetDefaults()
+		if globalConfig := Config.GetConfig(); globalConfig != nil {
+			config = &globalConfig.Monitoring
+		}
+	}
+
+	limits := make(map[string]int)
+	for _, entry := range splitNotificationList(config.Cardinality.Limits) {
+		metric, value, found := strings.Cut(entry, ":")
+		limit, err := strconv.Atoi(strings.TrimSpace(value))
+		if found && strings.TrimSpace(metric) != "" && err == nil && limit > 0 {
+			limits[strings.TrimSpace(metric)] = limit
+		}
+	}
+
+	return &MetricCardinalityGuard{
+		config:  config,
+		limits:  limits,
+		metrics: make(map[string]*metricCardinality),
+		now:     time.Now,
+	}
+}
+
+// OnAlert 设置基数告警处理函数
+func (g *MetricCardinalityGuard) OnAlert(handler func(MonitoringAlert)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.alertHandler = handler
+}
+
+// SetClock 设置时间函数，用于测试
+func (g *MetricCardinalityGuard) SetClock(now func() time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.now = now
+}
+
+// Limit 返回指标的序列数上限
+func (g *MetricCardinalityGuard) Limit(metric string) int {
+	if limit, ok := g.limits[metric]; ok {
+		return limit
+	}
+	return g.config.Cardinality.MaxSeries
+}
+
+// Check 校验采样标签并登记标签组合，返回实际写入使用的标签
+// 超过上限时按配置返回 ErrMetricCardinalityExceeded，或返回只包含 overflow="true" 的标签
+func (g *MetricCardinalityGuard) Check(metric string, labels map[string]string) (map[string]string, error) {
+	if g == nil || !g.config.Cardinality.Enabled {
+		return labels, nil
+	}
+
+	validationErr := g.validate(labels)
+	key := ingestSeriesName("", labels)
+
+	g.mu.Lock()
+	now := g.now()
+	state, exists := g.metrics[metric]
+	if !exists {
+		state = &metricCardinality{series: make(map[string]time.Time), lastAlerts: make(map[string]time.Time)}
+		g.metrics[metric] = state
+	}
+	if validationErr != nil {
+		state.invalidLabels++
+		g.mu.Unlock()
+		return nil, validationErr
+	}
+
+	limit := g.Limit(metric)
+	if _, tracked := state.series[key]; tracked {
+		state.series[key] = now
+		g.mu.Unlock()
+		return labels, nil
+	}
+	if len(state.series) >= limit {
+		g.prune(state, now)
+	}
+
+	var alerts []MonitoringAlert
+	var result map[string]string
+	var err error
+	if len(state.series) < limit {
+		state.series[key] = now
+		result = labels
+		if float64(len(state.series)) >= math.Ceil(float64(limit)*g.config.Cardinality.WarnRatio) {
+			alerts = g.alert(alerts, state, metric, MetricCardinalityAlertWarning, "medium",
+				fmt.Sprintf("指标 %s 的序列数 %d 已达到上限 %d 的 %.0f%%", metric, len(state.series), limit, g.config.Cardinality.WarnRatio*100), limit, now)
+		}
+	} else {
+		state.lastOverflowAt = now
+		if g.config.Cardinality.Action == Config.MetricCardinalityReject {
+			state.rejected++
+			err = fmt.Errorf("%w：指标 %s 已有 %d 个序列", ErrMetricCardinalityExceeded, metric, limit)
+		} else {
+			state.aggregated++
+			result = map[string]string{MetricCardinalityOverflowLabel: "true"}
+		}
+		alerts = g.alert(alerts, state, metric, MetricCardinalityAlertExceeded, "high",
+			fmt.Sprintf("指标 %s 的序列数超过上限 %d，新的标签组合已%s", metric, limit, cardinalityActionText(g.config.Cardinality.Action)), limit, now)
+	}
+	handler := g.alertHandler
+	g.mu.Unlock()
+
+	for _, alert := range alerts {
+		log.Printf("指标基数告警: %s - %s", alert.Type, alert.Message)
+		if handler != nil {
+			handler(alert)
+		}
+	}
+	return result, err
+}
+
+// Top 按序列占用比例从高到低返回指标基数统计，limit 小于等于0时返回全部
+func (g *MetricCardinalityGuard) Top(limit int) []MetricCardinalityStat {
+	if g == nil {
+		return []MetricCardinalityStat{}
+	}
+
+	g.mu.Lock()
+	now := g.now()
+	stats := make([]MetricCardinalityStat, 0, len(g.metrics))
+	for metric, state := range g.metrics {
+		g.prune(state, now)
+		stat := MetricCardinalityStat{
+			Metric:        metric,
+			Series:        len(state.series),
+			Limit:         g.Limit(metric),
+			Rejected:      state.rejected,
+			Aggregated:    state.aggregated,
+			InvalidLabels: state.invalidLabels,
+		}
+		stat.Usage = float64(stat.Series) / float64(stat.Limit)
+		if !state.lastOverflowAt.IsZero() {
+			at := state.lastOverflowAt
+			stat.LastOverflowAt = &at
+		}
+		stats = append(stats, stat)
+	}
+	g.mu.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Usage != stats[j].Usage {
+			return stats[i].Usage > stats[j].Usage
+		}
+		overflowI := stats[i].Rejected + stats[i].Aggregated
+		overflowJ := stats[j].Rejected + stats[j].Aggregated
+		if overflowI != overflowJ {
+			return overflowI > overflowJ
+		}
+		return stats[i].Metric < stats[j].Metric
+	})
+	if limit > 0 && len(stats) > limit {
+		stats = stats[:limit]
+	}
+	return stats
+}
+
+// validate 校验标签数量、名称和值长度
+func (g *MetricCardinalityGuard) validate(labels map[string]string) error {
+	if len(labels) > g.config.Cardinality.MaxLabels {
+		return fmt.Errorf("%w：标签数量超过 %d", ErrInvalidMetricLabels, g.config.Cardinality.MaxLabels)
+	}
+	for name, value := range labels {
+		if !ingestLabelNamePattern.MatchString(name) || strings.HasPrefix(name, "__") {
+			return fmt.Errorf("%w：标签名称 %q 不合法", ErrInvalidMetricLabels, name)
+		}
+		if len(value) > g.config.Cardinality.MaxLabelValueLength {
+			return fmt.Errorf("%w：标签 %s 的值超过 %d 个字符", ErrInvalidMetricLabels, name, g.config.Cardinality.MaxLabelValueLength)
+		}
+	}
+	return nil
+}
+
+// prune 删除超过 SeriesTTL 未出现的标签组合
+func (g *MetricCardinalityGuard) prune(state *metricCardinality, now time.Time) {
+	for key, seen := range state.series {
+		if now.Sub(seen) > g.config.Cardinality.SeriesTTL {
+			delete(state.series, key)
+		}
+	}
+}
+
+// alert 按冷却时间追加基数告警
+func (g *MetricCardinalityGuard) alert(alerts []MonitoringAlert, state *metricCardinality, metric, alertType, severity, message string, limit int, now time.Time) []MonitoringAlert {
+	if last, ok := state.lastAlerts[alertType]; ok && now.Sub(last) < g.config.Cardinality.AlertCooldown {
+		return alerts
+	}
+	state.lastAlerts[alertType] = now
+	return append(alerts, MonitoringAlert{
+		ID:        fmt.Sprintf("%s_%s_%d", alertType, metric, now.UnixNano()),
+		Type:      alertType,
+		Severity:  severity,
+		Title:     fmt.Sprintf("指标基数告警: %s", metric),
+		Message:   message,
+		Source:    "metric_cardinality_guard",
+		Timestamp: now,
+		Metadata: map[string]interface{}{
+			"metric":     metric,
+			"series":     len(state.series),
+			"limit":      limit,
+			"action":     g.config.Cardinality.Action,
+			"rejected":   state.rejected,
+			"aggregated": state.aggregated,
+		},
+	})
+}
+
+// cardinalityActionText 超过上限时处理方式的说明
+func cardinalityActionText(action string) string {
+	if action == Config.MetricCardinalityReject {
+		return "拒绝"
+	}
+	return "合并为 overflow 序列"
+}
@@ -0,0 +1,114 @@
+package Services
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// MetricCollector 指标采集器，监控核心对外提供的扩展点
+// 功能说明：
+// 1. 业务模块实现该接口并通过 MonitoringCore.RegisterCollector 注册，无需修改监控核心即可接入自定义指标
+// 2. Collect 返回按指标名称索引的数值，同名指标以注册顺序靠后的采集器为准，指标名称建议带业务前缀避免冲突
+// 3. Collect 应遵循 ctx 的取消，超时后返回的结果会被丢弃
+// 4. 可选实现 CollectorInitializer、CollectorCloser 获得初始化和关闭回调
+//
+// 示例：
+//
+//	core := Services.DefaultMonitoringCore()
+//	err := core.RegisterCollectorWithOptions(
+//		Services.NewMetricCollector("orders", func(ctx context.Context) (map[string]float64, error) {
+//			return map[string]float64{"orders_pending": float64(countPendingOrders(ctx))}, nil
+//		}),
+//		Services.CollectorOptions{Interval: time.Minute, Timeout: 5 * time.Second},
+//	)
+type MetricCollector interface {
+	Name() string
+	Collect(ctx context.Context) (map[string]float64, error)
+}
+
+// CollectorInitializer 需要初始化的采集器，注册时调用，返回错误时不注册
+type CollectorInitializer interface {
+	Init(ctx context.Context) error
+}
+
+// CollectorCloser 需要释放资源的采集器，注销、被同名采集器替换或监控核心关闭时调用
+type CollectorCloser interface {
+	Close() error
+}
+
+// CollectorOptions 采集器调度选项
+type CollectorOptions struct {
+	// Interval 采集间隔，为0时每轮采集都执行；小于监控核心的采集间隔时按核心间隔执行
+	Interval time.Duration
+	// Timeout 单次采集超时，为0时使用监控核心的默认超时
+	Timeout time.Duration
+}
+
+// CollectorStatus 采集器运行状态
+type CollectorStatus struct {
+	Name         string        `json:"name"`
+	Interval     time.Duration `json:"interval"`
+	Timeout      time.Duration `json:"timeout"`
+	LastRunAt    time.Time     `json:"last_run_at"`
+	LastDuration time.Duration `json:"last_duration"`
+	LastError    string        `json:"last_error,omitempty"`
+	Failures     int64         `json:"failures"` // 连续失败次数，成功后清零
+}
+
+// metricCollectorFunc 使用函数实现的采集器
+type metricCollectorFunc struct {
+	name    string
+	collect func(ctx context.Context) (map[string]float64, error)
+}
+
+func (c metricCollectorFunc) Name() string { return c.name }
+
+func (c metricCollectorFunc) Collect(ctx context.Context) (map[string]float64, error) {
+	return c.collect(ctx)
+}
+
+// NewMetricCollector 使用函数创建指标采集器
+func NewMetricCollector(name string, collect func(ctx context.Context) (map[string]float64, error)) MetricCollector {
+	return metricCollectorFunc{name: name, collect: collect}
+}
+
+// safeCollect 执行一次采集，采集器 panic 时转换为错误，避免影响监控核心和其他采集器
+func safeCollect(ctx context.Context, collector MetricCollector) (values map[string]float64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			values = nil
+			err = fmt.Errorf("collector panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return collector.Collect(ctx)
+}
+
+// safeInit 调用采集器的初始化回调，panic 时转换为错误
+func safeInit(ctx context.Context, collector MetricCollector) (err error) {
+	initializer, ok := collector.(CollectorInitializer)
+	if !ok {
+		return nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("collector init panic: %v", r)
+		}
+	}()
+	return initializer.Init(ctx)
+}
+
+// safeClose 调用采集器的关闭回调，panic 时转换为错误
+func safeClose(collector MetricCollector) (err error) {
+	closer, ok := collector.(CollectorCloser)
+	if !ok {
+		return nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("collector close panic: %v", r)
+		}
+	}()
+	return closer.Close()
+}
@@ -0,0 +1,107 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MetricHistoryService 指标历史服务
+// 功能说明：
+// 1. 保存数值型指标的历史采样，供动态阈值告警和规则回测按时间范围查询
+// 2. 保留期使用监控存储配置（MONITORING_STORAGE_DATABASE_RETENTION），定期清理过期采样
+type MetricHistoryService struct {
+	db        *gorm.DB
+	retention time.Duration
+}
+
+// NewMetricHistoryService 创建指标历史服务
+//
+// config 为 nil 时使用全局监控配置。
+func NewMetricHistoryService(db *gorm.DB, config *Config.MonitoringConfig) *MetricHistoryService {
+	if config == nil {
+		config = &Config.MonitoringConfig{}
+		config.SetDefaults()
+		if globalConfig := Config.GetConfig(); globalConfig != nil {
+			config = &globalConfig.Monitoring
+		}
+	}
+	return &MetricHistoryService{
+		db:        db,
+		retention: config.StorageConfig.Database.Retention,
+	}
+}
+
+// Record 记录一个采样
+func (s *MetricHistoryService) Record(name string, value float64, at time.Time) error {
+	return s.db.Create(&Models.MetricSample{Name: name, Value: value, Timestamp: at}).Error
+}
+
+// RecordBatch 批量记录采样
+func (s *MetricHistoryService) RecordBatch(samples []Models.MetricSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	return s.db.CreateInBatches(samples, 500).Error
+}
+
+// Query 查询指标在 [start, end] 内的采样，按时间升序
+func (s *MetricHistoryService) Query(name string, start, end time.Time) ([]Models.MetricSample, error) {
+	var samples []Models.MetricSample
+	err := s.db.Where("name = ? AND timestamp >= ? AND timestamp <= ?", name, start, end).
+		Order("timestamp ASC").
+		Find(&samples).Error
+	return samples, err
+}
+
+// Latest 查询各指标最近一个采样，没有采样的指标不包含在结果中
+func (s *MetricHistoryService) Latest(names []string) (map[string]Models.MetricSample, error) {
+	latest := make(map[string]Models.MetricSample, len(names))
+	for _, name := range names {
+		var samples []Models.MetricSample
+		if err := s.db.Where("name = ?", name).Order("timestamp DESC").Limit(1).Find(&samples).Error; err != nil {
+			return nil, err
+		}
+		if len(samples) > 0 {
+			latest[name] = samples[0]
+		}
+	}
+	return latest, nil
+}
+
+// Cleanup 分批删除超过保留期的采样，由清理调度器定期运行
+func (s *MetricHistoryService) Cleanup(ctx context.Context) (int64, error) {
+	if s.retention <= 0 {
+		return 0, nil
+	}
+	return BatchDelete(ctx, s.db.Where("timestamp < ?", time.Now().Add(-s.retention)), &Models.MetricSample{})
+}
+
+// metricSampleValue 将指标值转换为数值，非数值类型返回 false
+func metricSampleValue(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case time.Duration:
+		return float64(v) / float64(time.Millisecond), true
+	default:
+		return 0, false
+	}
+}
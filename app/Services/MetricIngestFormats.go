@@ -0,0 +1,409 @@
+package Services
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// 指标推送支持的数据格式
+const (
+	IngestFormatJSON        = "json"
+	IngestFormatInflux      = "influx"
+	IngestFormatRemoteWrite = "prometheus_remote_write"
+)
+
+// IngestSample 外部推送的指标采样
+// Timestamp 为零值时使用接收时间
+type IngestSample struct {
+	Name      string            `json:"name"`
+	Value     float64           `json:"value"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+// ParseIngestJSON 解析JSON格式的指标批次
+// 格式：{"metrics":[{"name":"job_duration_seconds","value":12.5,"labels":{"job":"billing"},"timestamp":"2024-01-01T00:00:00Z"}]}
+// 缺少 value 的采样以 NaN 表示，由校验阶段拒绝
+func ParseIngestJSON(body []byte) ([]IngestSample, error) {
+	var payload struct {
+		Metrics []struct {
+			Name      string            `json:"name"`
+			Value     *float64          `json:"value"`
+			Labels    map[string]string `json:"labels"`
+			Timestamp time.Time         `json:"timestamp"`
+		} `json:"metrics"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid JSON payload: %w", err)
+	}
+
+	samples := make([]IngestSample, 0, len(payload.Metrics))
+	for _, metric := range payload.Metrics {
+		value := math.NaN()
+		if metric.Value != nil {
+			value = *metric.Value
+		}
+		samples = append(samples, IngestSample{Name: metric.Name, Value: value, Labels: metric.Labels, Timestamp: metric.Timestamp})
+	}
+	return samples, nil
+}
+
+// ParseInfluxLineProtocol 解析Influx行协议
+// 功能说明：
+// 1. 每行格式：measurement[,tag=value...] field=value[,field=value...] [timestamp]
+// 2. 字段名为 value 时指标名称为 measurement，否则为 measurement_field
+// 3. 整数（i/u 后缀）、浮点数和布尔值转换为数值，字符串字段忽略
+// 4. precision 为时间戳精度：ns（默认）、us、ms、s
+func ParseInfluxLineProtocol(body []byte, precision string) ([]IngestSample, error) {
+	unit, err := influxPrecision(precision)
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []IngestSample
+	for number, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parsed, err := parseInfluxLine(line, unit)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", number+1, err)
+		}
+		samples = append(samples, parsed...)
+	}
+	return samples, nil
+}
+
+// influxPrecision 时间戳精度对应的时间单位
+func influxPrecision(precision string) (time.Duration, error) {
+	switch precision {
+	case "", "ns", "n":
+		return time.Nanosecond, nil
+	case "us", "u":
+		return time.Microsecond, nil
+	case "ms":
+		return time.Millisecond, nil
+	case "s":
+		return time.Second, nil
+	default:
+		return 0, fmt.Errorf("unsupported precision %q", precision)
+	}
+}
+
+// parseInfluxLine 解析单行数据
+func parseInfluxLine(line string, unit time.Duration) ([]IngestSample, error) {
+	sections := splitInflux(line, ' ')
+	if len(sections) != 2 && len(sections) != 3 {
+		return nil, fmt.Errorf("expected measurement, fields and optional timestamp")
+	}
+
+	keys := splitInflux(sections[0], ',')
+	measurement := unescapeInflux(keys[0])
+	if measurement == "" {
+		return nil, fmt.Errorf("missing measurement")
+	}
+	labels := make(map[string]string, len(keys)-1)
+	for _, tag := range keys[1:] {
+		name, value, found := cutInflux(tag)
+		if !found {
+			return nil, fmt.Errorf("invalid tag %q", tag)
+		}
+		labels[name] = value
+	}
+
+	var timestamp time.Time
+	if len(sections) == 3 {
+		ts, err := strconv.ParseInt(sections[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q", sections[2])
+		}
+		timestamp = time.Unix(0, ts*int64(unit))
+	}
+
+	var samples []IngestSample
+	for _, field := range splitInflux(sections[1], ',') {
+		name, raw, found := cutInflux(field)
+		if !found || raw == "" {
+			return nil, fmt.Errorf("invalid field %q", field)
+		}
+		if strings.HasPrefix(raw, `"`) {
+			continue
+		}
+		value, err := parseInfluxFieldValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", name, err)
+		}
+
+		metricName := measurement + "_" + name
+		if name == "value" {
+			metricName = measurement
+		}
+		samples = append(samples, IngestSample{Name: metricName, Value: value, Labels: labels, Timestamp: timestamp})
+	}
+	return samples, nil
+}
+
+// parseInfluxFieldValue 解析数值、整数和布尔字段
+func parseInfluxFieldValue(raw string) (float64, error) {
+	switch raw {
+	case "t", "T", "true", "True", "TRUE":
+		return 1, nil
+	case "f", "F", "false", "False", "FALSE":
+		return 0, nil
+	}
+	if strings.HasSuffix(raw, "i") || strings.HasSuffix(raw, "u") {
+		value, err := strconv.ParseInt(raw[:len(raw)-1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid integer %q", raw)
+		}
+		return float64(value), nil
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", raw)
+	}
+	return value, nil
+}
+
+// splitInflux 按分隔符切分，跳过转义字符和双引号内的分隔符
+func splitInflux(s string, sep byte) []string {
+	var parts []string
+	start, quoted := 0, false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// cutInflux 在第一个未转义的等号处切分键值，键去除转义
+func cutInflux(s string) (string, string, bool) {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '=':
+			return unescapeInflux(s[:i]), unescapeInflux(s[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// unescapeInflux 去除逗号、空格、等号的转义
+func unescapeInflux(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	return strings.NewReplacer(`\,`, ",", `\ `, " ", `\=`, "=").Replace(s)
+}
+
+// ParsePrometheusRemoteWrite 解析Prometheus remote-write 请求体（snappy压缩的 WriteRequest）
+// 指标名称取自 __name__ 标签，其余标签原样保留；直方图和样例数据忽略
+func ParsePrometheusRemoteWrite(body []byte, maxDecodedSize int) ([]IngestSample, error) {
+	data, err := decodeSnappyBlock(body, maxDecodedSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snappy payload: %w", err)
+	}
+
+	var samples []IngestSample
+	err = walkProtoMessage(data, func(num protowire.Number, raw []byte, _ uint64) error {
+		if num != 1 { // WriteRequest.timeseries
+			return nil
+		}
+		series, err := parseRemoteWriteSeries(raw)
+		if err != nil {
+			return err
+		}
+		samples = append(samples, series...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote-write payload: %w", err)
+	}
+	return samples, nil
+}
+
+// parseRemoteWriteSeries 解析 TimeSeries：labels=1、samples=2
+func parseRemoteWriteSeries(data []byte) ([]IngestSample, error) {
+	var name string
+	labels := make(map[string]string)
+	var points []IngestSample
+
+	err := walkProtoMessage(data, func(num protowire.Number, raw []byte, _ uint64) error {
+		switch num {
+		case 1:
+			var labelName, labelValue string
+			err := walkProtoMessage(raw, func(num protowire.Number, raw []byte, _ uint64) error {
+				switch num {
+				case 1:
+					labelName = string(raw)
+				case 2:
+					labelValue = string(raw)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if labelName == "__name__" {
+				name = labelValue
+			} else {
+				labels[labelName] = labelValue
+			}
+		case 2:
+			var point IngestSample
+			err := walkProtoMessage(raw, func(num protowire.Number, _ []byte, scalar uint64) error {
+				switch num {
+				case 1:
+					point.Value = math.Float64frombits(scalar)
+				case 2:
+					point.Timestamp = time.UnixMilli(int64(scalar))
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			points = append(points, point)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range points {
+		points[i].Name = name
+		points[i].Labels = labels
+	}
+	return points, nil
+}
+
+// walkProtoMessage 依次访问protobuf消息的字段
+// 长度分隔字段传入 raw，varint 和定长字段传入 scalar
+func walkProtoMessage(data []byte, visit func(num protowire.Number, raw []byte, scalar uint64) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		var raw []byte
+		var scalar uint64
+		switch typ {
+		case protowire.BytesType:
+			raw, n = protowire.ConsumeBytes(data)
+		case protowire.VarintType:
+			scalar, n = protowire.ConsumeVarint(data)
+		case protowire.Fixed64Type:
+			scalar, n = protowire.ConsumeFixed64(data)
+		case protowire.Fixed32Type:
+			var value uint32
+			value, n = protowire.ConsumeFixed32(data)
+			scalar = uint64(value)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		if err := visit(num, raw, scalar); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeSnappyBlock 解码snappy块格式（remote-write 使用的非流式格式）
+// 解码后长度超过 maxLen 时拒绝，防止压缩炸弹
+func decodeSnappyBlock(src []byte, maxLen int) ([]byte, error) {
+	length, n := binary.Uvarint(src)
+	if n <= 0 {
+		return nil, fmt.Errorf("invalid length header")
+	}
+	if length > uint64(maxLen) {
+		return nil, fmt.Errorf("decoded size %d exceeds limit %d", length, maxLen)
+	}
+	src = src[n:]
+	dst := make([]byte, 0, length)
+
+	for len(src) > 0 {
+		tag := src[0]
+		var size, offset int
+		switch tag & 0x03 {
+		case 0x00: // 字面量
+			size = int(tag >> 2)
+			src = src[1:]
+			if size >= 60 {
+				extra := size - 59
+				if len(src) < extra {
+					return nil, fmt.Errorf("truncated literal")
+				}
+				size = 0
+				for i := 0; i < extra; i++ {
+					size |= int(src[i]) << (8 * i)
+				}
+				src = src[extra:]
+			}
+			size++
+			if size > len(src) || len(dst)+size > int(length) {
+				return nil, fmt.Errorf("literal out of range")
+			}
+			dst = append(dst, src[:size]...)
+			src = src[size:]
+			continue
+		case 0x01: // 1字节偏移的复制
+			if len(src) < 2 {
+				return nil, fmt.Errorf("truncated copy")
+			}
+			size = 4 + int(tag>>2&0x07)
+			offset = int(tag&0xe0)<<3 | int(src[1])
+			src = src[2:]
+		case 0x02: // 2字节偏移的复制
+			if len(src) < 3 {
+				return nil, fmt.Errorf("truncated copy")
+			}
+			size = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint16(src[1:3]))
+			src = src[3:]
+		case 0x03: // 4字节偏移的复制
+			if len(src) < 5 {
+				return nil, fmt.Errorf("truncated copy")
+			}
+			size = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint32(src[1:5]))
+			src = src[5:]
+		}
+
+		if offset <= 0 || offset > len(dst) || len(dst)+size > int(length) {
+			return nil, fmt.Errorf("copy out of range")
+		}
+		// 偏移小于长度时复制区域与输出重叠，需要逐字节复制
+		for i := 0; i < size; i++ {
+			dst = append(dst, dst[len(dst)-offset])
+		}
+	}
+
+	if len(dst) != int(length) {
+		return nil, fmt.Errorf("decoded size mismatch")
+	}
+	return dst, nil
+}
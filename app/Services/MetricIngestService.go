@@ -0,0 +1,311 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrIngestUnauthorized 推送令牌无效
+	ErrIngestUnauthorized = errors.New("推送令牌无效")
+	// ErrIngestBatchTooLarge 单次推送的采样数超过限制
+	ErrIngestBatchTooLarge = errors.New("推送批次过大")
+	// ErrUnsupportedIngestFormat 不支持的推送格式
+	ErrUnsupportedIngestFormat = errors.New("不支持的推送格式")
+)
+
+// MetricIngestQuotaError 来源超过每分钟推送限额时返回该错误，RetryAfter 为当前窗口剩余时间
+type MetricIngestQuotaError struct {
+	Source     string
+	Limit      int
+	RetryAfter time.Duration
+}
+
+func (e *MetricIngestQuotaError) Error() string {
+	return fmt.Sprintf("来源 %s 超过每分钟 %d 个采样的推送限额，请在%v后重试", e.Source, e.Limit, e.RetryAfter.Round(time.Second))
+}
+
+// MetricIngestResult 推送结果
+type MetricIngestResult struct {
+	Source   string   `json:"source"`
+	Accepted int      `json:"accepted"`
+	Rejected int      `json:"rejected"`
+	Errors   []string `json:"errors,omitempty"` // 最多返回前 maxIngestErrors 条拒绝原因
+}
+
+// maxIngestErrors 推送结果中返回的拒绝原因条数上限
+const maxIngestErrors = 20
+
+// maxIngestLabels 单个采样的标签数量上限
+const maxIngestLabels = 20
+
+// maxIngestSeriesLength 序列名称（指标名称加标签）的长度上限，与指标历史表的名称字段一致
+const maxIngestSeriesLength = 100
+
+var (
+	ingestMetricNamePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	ingestLabelNamePattern  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// ingestQuotaWindow 来源在当前一分钟窗口内已接收的采样数
+type ingestQuotaWindow struct {
+	start time.Time
+	used  int
+}
+
+// MetricIngestService 外部指标推送服务
+// 功能说明：
+// 1. 按来源令牌认证推送方，令牌在 MONITORING_INGEST_TOKENS 中按 来源:令牌 配置
+// 2. 支持JSON、Influx行协议和Prometheus remote-write 三种格式
+// 3. 校验指标名称、标签、数值和时间戳，不合法的采样逐条拒绝，其余采样正常接收
+// 4. 按来源限制每分钟接收的采样数，整批超过剩余额度时拒绝整批，便于推送方按 Retry-After 重试
+// 5. 接收的采样写入监控核心作为最新指标值参与告警评估，设置了指标历史时同时保存历史
+// 6. 启用用量计量时按来源统计接收的采样数，超过本周期限额时拒绝整批
+// 7. 设置了指标基数保护时，超过序列数上限的新标签组合逐条拒绝或合并为 overflow 序列
+//
+// 带标签的采样以 Prometheus 序列写法作为指标名称，如 job_duration_seconds{job="billing"}
+type MetricIngestService struct {
+	config  *Config.MonitoringConfig
+	sources map[string]string // 令牌 -> 来源
+
+	mu      sync.Mutex
+	windows map[string]*ingestQuotaWindow
+
+	core     *MonitoringCore
+	history  *MetricHistoryService
+	writer   *MetricBatchWriter
+	metering *MeteringService
+	guard    *MetricCardinalityGuard
+}
+
+// NewMetricIngestService 创建外部指标推送服务
+func NewMetricIngestService(config *Config.MonitoringConfig) *MetricIngestService {
+	if config == nil {
+		config = &Config.MonitoringConfig{}
+		config.SetDefaults()
+		if globalConfig := Config.GetConfig(); globalConfig != nil {
+			config = &globalConfig.Monitoring
+		}
+	}
+
+	sources := make(map[string]string)
+	for _, entry := range splitNotificationList(config.Ingest.Tokens) {
+		source, token, found := strings.Cut(entry, ":")
+		if found && strings.TrimSpace(source) != "" && strings.TrimSpace(token) != "" {
+			sources[strings.TrimSpace(token)] = strings.TrimSpace(source)
+		}
+	}
+
+	return &MetricIngestService{
+		config:   config,
+		sources:  sources,
+		windows:  make(map[string]*ingestQuotaWindow),
+		core:     DefaultMonitoringCore(),
+		metering: DefaultMeteringService(),
+	}
+}
+
+// SetMonitoringCore 设置接收指标的监控核心
+func (s *MetricIngestService) SetMonitoringCore(core *MonitoringCore) {
+	s.core = core
+}
+
+// SetMetricHistory 设置指标历史，接收的采样同时写入历史供动态阈值和回测使用
+func (s *MetricIngestService) SetMetricHistory(history *MetricHistoryService) {
+	s.history = history
+}
+
+// SetMetricBatchWriter 设置指标批量写入器，设置后接收的采样经写入队列异步保存，不再直接写入指标历史
+func (s *MetricIngestService) SetMetricBatchWriter(writer *MetricBatchWriter) {
+	s.writer = writer
+}
+
+// SetMeteringService 设置用量计量服务，统计来源推送的采样数并检查限额
+func (s *MetricIngestService) SetMeteringService(metering *MeteringService) {
+	s.metering = metering
+}
+
+// SetCardinalityGuard 设置指标基数保护，带标签的采样按指标名称限制序列数
+func (s *MetricIngestService) SetCardinalityGuard(guard *MetricCardinalityGuard) {
+	s.guard = guard
+}
+
+// MaxBodySize 单次请求体最大字节数
+func (s *MetricIngestService) MaxBodySize() int64 {
+	return s.config.Ingest.MaxBodySize
+}
+
+// Authenticate 校验推送令牌，返回令牌对应的来源
+func (s *MetricIngestService) Authenticate(token string) (string, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", ErrIngestUnauthorized
+	}
+	// 逐个常量时间比较，避免通过响应时间猜测令牌
+	matched := ""
+	for candidate, source := range s.sources {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+			matched = source
+		}
+	}
+	if matched == "" {
+		return "", ErrIngestUnauthorized
+	}
+	return matched, nil
+}
+
+// Parse 按格式解析请求体，precision 只对Influx行协议生效
+func (s *MetricIngestService) Parse(format string, body []byte, precision string) ([]IngestSample, error) {
+	switch format {
+	case IngestFormatJSON:
+		return ParseIngestJSON(body)
+	case IngestFormatInflux:
+		return ParseInfluxLineProtocol(body, precision)
+	case IngestFormatRemoteWrite:
+		// 解码后的大小按请求体上限的10倍限制
+		return ParsePrometheusRemoteWrite(body, int(s.config.Ingest.MaxBodySize)*10)
+	default:
+		return nil, ErrUnsupportedIngestFormat
+	}
+}
+
+// Ingest 校验并接收来源推送的采样
+func (s *MetricIngestService) Ingest(source string, samples []IngestSample) (*MetricIngestResult, error) {
+	if len(samples) > s.config.Ingest.MaxBatchSize {
+		return nil, fmt.Errorf("%w：%d 个采样，上限 %d", ErrIngestBatchTooLarge, len(samples), s.config.Ingest.MaxBatchSize)
+	}
+
+	now := time.Now()
+	result := &MetricIngestResult{Source: source}
+	accepted := make([]Models.MetricSample, 0, len(samples))
+	for i, sample := range samples {
+		series, err := s.validate(sample, now)
+		if err != nil {
+			result.Rejected++
+			if len(result.Errors) < maxIngestErrors {
+				result.Errors = append(result.Errors, fmt.Sprintf("第 %d 个采样 %s: %v", i+1, sample.Name, err))
+			}
+			continue
+		}
+		at := sample.Timestamp
+		if at.IsZero() {
+			at = now
+		}
+		accepted = append(accepted, Models.MetricSample{Name: series, Value: sample.Value, Timestamp: at})
+	}
+
+	if s.metering != nil {
+		if err := s.metering.Check(SourceTenant(source), UsageMetricMetricsIngested, int64(len(accepted))); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.reserve(source, len(accepted), now); err != nil {
+		return nil, err
+	}
+	if s.metering != nil {
+		s.metering.Record(SourceTenant(source), UsageMetricMetricsIngested, int64(len(accepted)))
+	}
+
+	// 同一序列按时间顺序写入，最新值以时间最晚的采样为准
+	sort.SliceStable(accepted, func(i, j int) bool { return accepted[i].Timestamp.Before(accepted[j].Timestamp) })
+	for _, sample := range accepted {
+		s.core.Observe(sample.Name, sample.Value)
+	}
+	if s.writer != nil && len(accepted) > 0 {
+		s.writer.Write(accepted)
+	} else if s.history != nil && len(accepted) > 0 {
+		if err := s.history.RecordBatch(accepted); err != nil {
+			log.Printf("保存推送指标历史失败: source=%s, error=%v", source, err)
+		}
+	}
+
+	result.Accepted = len(accepted)
+	return result, nil
+}
+
+// validate 校验单个采样，返回序列名称
+func (s *MetricIngestService) validate(sample IngestSample, now time.Time) (string, error) {
+	if !ingestMetricNamePattern.MatchString(sample.Name) {
+		return "", fmt.Errorf("指标名称不合法")
+	}
+	if math.IsNaN(sample.Value) || math.IsInf(sample.Value, 0) {
+		return "", fmt.Errorf("指标值无效")
+	}
+	if len(sample.Labels) > maxIngestLabels {
+		return "", fmt.Errorf("标签数量超过 %d", maxIngestLabels)
+	}
+	for name := range sample.Labels {
+		if !ingestLabelNamePattern.MatchString(name) || strings.HasPrefix(name, "__") {
+			return "", fmt.Errorf("标签名称 %q 不合法", name)
+		}
+	}
+	if !sample.Timestamp.IsZero() {
+		if sample.Timestamp.Before(now.Add(-s.config.Ingest.MaxSampleAge)) {
+			return "", fmt.Errorf("采样时间早于 %v", s.config.Ingest.MaxSampleAge)
+		}
+		// 允许少量时钟偏差
+		if sample.Timestamp.After(now.Add(5 * time.Minute)) {
+			return "", fmt.Errorf("采样时间晚于当前时间")
+		}
+	}
+
+	series := ingestSeriesName(sample.Name, sample.Labels)
+	if len(series) > maxIngestSeriesLength {
+		return "", fmt.Errorf("序列名称超过 %d 个字符", maxIngestSeriesLength)
+	}
+	// 校验通过后才登记标签组合，避免被拒绝的采样占用序列数
+	labels, err := s.guard.Check(sample.Name, sample.Labels)
+	if err != nil {
+		return "", err
+	}
+	return ingestSeriesName(sample.Name, labels), nil
+}
+
+// reserve 占用来源当前窗口的推送额度
+func (s *MetricIngestService) reserve(source string, count int, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	window, exists := s.windows[source]
+	if !exists || now.Sub(window.start) >= time.Minute {
+		window = &ingestQuotaWindow{start: now}
+		s.windows[source] = window
+	}
+	if window.used+count > s.config.Ingest.QuotaPerMinute {
+		return &MetricIngestQuotaError{
+			Source:     source,
+			Limit:      s.config.Ingest.QuotaPerMinute,
+			RetryAfter: window.start.Add(time.Minute).Sub(now),
+		}
+	}
+	window.used += count
+	return nil
+}
+
+// ingestSeriesName 按 Prometheus 序列写法拼接指标名称和标签，标签按名称排序
+func ingestSeriesName(name string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return name
+	}
+	names := make([]string, 0, len(labels))
+	for label := range labels {
+		names = append(names, label)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, label := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", label, labels[label]))
+	}
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
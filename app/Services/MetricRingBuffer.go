@@ -0,0 +1,173 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Models"
+	"sync"
+	"time"
+)
+
+// 指标数据来源
+const (
+	MetricSourceMemory   = "memory"   // 最近采样环形缓冲区
+	MetricSourceDatabase = "database" // 指标历史表
+	MetricSourceNone     = "none"     // 没有可用数据
+)
+
+const (
+	// defaultMetricRingBufferSize 每个指标默认保留的最近采样数，按30秒采集间隔约3小时
+	defaultMetricRingBufferSize = 360
+	// defaultMetricStaleAfter 默认的指标过期时间，超过该时长未更新视为过期
+	defaultMetricStaleAfter = 90 * time.Second
+)
+
+// MetricFreshness 指标数据的来源和新鲜度
+type MetricFreshness struct {
+	Source     string     `json:"source"`               // memory、database 或 none
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"` // 最近一个采样的时间
+	AgeSeconds float64    `json:"age_seconds"`          // 最近一个采样距今的秒数
+	Stale      bool       `json:"stale"`                // 超过过期时间未更新或没有数据
+}
+
+// newMetricFreshness 按最近采样时间计算新鲜度，updatedAt 为零值时视为没有数据
+func newMetricFreshness(source string, updatedAt, now time.Time, staleAfter time.Duration) MetricFreshness {
+	if updatedAt.IsZero() {
+		return MetricFreshness{Source: MetricSourceNone, Stale: true}
+	}
+	if staleAfter <= 0 {
+		staleAfter = defaultMetricStaleAfter
+	}
+	age := now.Sub(updatedAt)
+	if age < 0 {
+		age = 0
+	}
+	return MetricFreshness{
+		Source:     source,
+		UpdatedAt:  &updatedAt,
+		AgeSeconds: age.Seconds(),
+		Stale:      age > staleAfter,
+	}
+}
+
+// MetricRingBuffer 最近指标采样的环形缓冲区
+// 功能说明：
+// 1. 每个指标保留最近 N 个采样，写满后覆盖最旧的采样，内存占用固定
+// 2. 由监控核心在每轮采集和直接上报时写入，仪表板和健康检查的热路径直接读取，不查询数据库
+// 3. 缓冲区未覆盖查询范围（如刚启动）时由调用方回退到指标历史
+type MetricRingBuffer struct {
+	mu     sync.RWMutex
+	size   int
+	series map[string]*metricRing
+}
+
+// metricRing 单个指标的采样环
+type metricRing struct {
+	samples []Models.MetricSample
+	next    int
+	full    bool
+}
+
+// NewMetricRingBuffer 创建环形缓冲区，size 不大于0时使用默认容量
+func NewMetricRingBuffer(size int) *MetricRingBuffer {
+	if size <= 0 {
+		size = defaultMetricRingBufferSize
+	}
+	return &MetricRingBuffer{size: size, series: make(map[string]*metricRing)}
+}
+
+// Size 返回每个指标保留的采样数
+func (b *MetricRingBuffer) Size() int {
+	return b.size
+}
+
+// Record 写入一个采样
+func (b *MetricRingBuffer) Record(name string, value float64, at time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.record(name, value, at)
+}
+
+// RecordValues 以相同的时间写入一组采样
+func (b *MetricRingBuffer) RecordValues(values map[string]float64, at time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for name, value := range values {
+		b.record(name, value, at)
+	}
+}
+
+// record 写入采样，调用方持有写锁
+func (b *MetricRingBuffer) record(name string, value float64, at time.Time) {
+	ring, exists := b.series[name]
+	if !exists {
+		ring = &metricRing{samples: make([]Models.MetricSample, 0, b.size)}
+		b.series[name] = ring
+	}
+	sample := Models.MetricSample{Name: name, Value: value, Timestamp: at}
+	if !ring.full {
+		ring.samples = append(ring.samples, sample)
+		if len(ring.samples) == b.size {
+			ring.full = true
+		}
+		return
+	}
+	ring.samples[ring.next] = sample
+	ring.next = (ring.next + 1) % b.size
+}
+
+// Latest 返回指标最近一个采样
+func (b *MetricRingBuffer) Latest(name string) (Models.MetricSample, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	ring, exists := b.series[name]
+	if !exists || len(ring.samples) == 0 {
+		return Models.MetricSample{}, false
+	}
+	return ring.samples[ring.newest()], true
+}
+
+// Range 返回指标在 [start, end] 内的采样，按时间升序
+// 最旧的采样晚于 start 时缓冲区没有覆盖整个范围，covered 为 false，调用方应回退到指标历史
+func (b *MetricRingBuffer) Range(name string, start, end time.Time) ([]Models.MetricSample, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	ring, exists := b.series[name]
+	if !exists || len(ring.samples) == 0 {
+		return nil, false
+	}
+	ordered := ring.ordered()
+	covered := !ordered[0].Timestamp.After(start)
+	samples := make([]Models.MetricSample, 0, len(ordered))
+	for _, sample := range ordered {
+		if !sample.Timestamp.Before(start) && !sample.Timestamp.After(end) {
+			samples = append(samples, sample)
+		}
+	}
+	return samples, covered
+}
+
+// Metrics 返回缓冲区中的指标数
+func (b *MetricRingBuffer) Metrics() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.series)
+}
+
+// newest 最近一个采样的下标
+func (r *metricRing) newest() int {
+	if !r.full {
+		return len(r.samples) - 1
+	}
+	return (r.next + len(r.samples) - 1) % len(r.samples)
+}
+
+// ordered 按写入顺序（时间升序）返回采样
+func (r *metricRing) ordered() []Models.MetricSample {
+	if !r.full {
+		return r.samples
+	}
+	ordered := make([]Models.MetricSample, 0, len(r.samples))
+	ordered = append(ordered, r.samples[r.next:]...)
+	return append(ordered, r.samples[:r.next]...)
+}
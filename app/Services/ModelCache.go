@@ -0,0 +1,348 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// usersTable 用户表名，与GORM默认命名一致
+const usersTable = "users"
+
+// ModelCache 热点模型的读穿透缓存
+// 功能说明：
+// 1. 按表分组缓存查询结果，命中时不访问数据库，未命中时调用加载函数并写入缓存
+// 2. 同一个键的并发未命中只执行一次加载，其他请求等待并共享结果（防止缓存击穿）
+// 3. 安装GORM回调后，任何通过GORM写入的表在提交后整表失效，避免条件更新漏掉缓存项
+// 4. 按表统计命中、未命中、加载、失效和淘汰次数，通过 Collector 输出到监控核心
+//
+// 注意事项：
+// - 加载失败（包括记录不存在）的结果不缓存
+// - 在手动开启的事务中写入时，失效发生在提交之前，提交前读取到的旧数据最多保留 TTL
+// - 缓存只在当前实例内，其他实例的写入最多延迟 TTL 后可见
+type ModelCache struct {
+	ttl        time.Duration
+	maxEntries int
+	mu         sync.Mutex
+	tables     map[string]*modelCacheTable
+	size       int
+	flights    map[string]*modelCacheFlight
+	now        func() time.Time
+}
+
+// modelCacheTable 一个表的缓存条目和统计
+// generation 在失效时递增，加载开始后表被失效时不写入加载结果
+type modelCacheTable struct {
+	entries    map[string]modelCacheEntry
+	generation uint64
+	stats      ModelCacheStats
+}
+
+// modelCacheEntry 缓存条目
+type modelCacheEntry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+// modelCacheFlight 进行中的加载
+type modelCacheFlight struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// ModelCacheStats 表的缓存统计
+type ModelCacheStats struct {
+	Table         string  `json:"table"`
+	Entries       int     `json:"entries"`
+	Hits          int64   `json:"hits"`
+	Misses        int64   `json:"misses"`
+	Loads         int64   `json:"loads"`  // 实际执行加载的次数
+	Shared        int64   `json:"shared"` // 等待其他请求加载结果的次数
+	Errors        int64   `json:"errors"`
+	Invalidations int64   `json:"invalidations"`
+	Evictions     int64   `json:"evictions"`
+	HitRate       float64 `json:"hit_rate"` // 命中率（百分比）
+}
+
+// NewModelCache 创建模型缓存，config 为 nil 时有效期60秒、最多10000个条目
+func NewModelCache(config *Config.ModelCacheConfig) *ModelCache {
+	cache := &ModelCache{
+		ttl:        time.Minute,
+		maxEntries: 10000,
+		tables:     make(map[string]*modelCacheTable),
+		flights:    make(map[string]*modelCacheFlight),
+		now:        time.Now,
+	}
+	if config != nil {
+		if config.TTL > 0 {
+			cache.ttl = config.TTL
+		}
+		if config.MaxEntries > 0 {
+			cache.maxEntries = config.MaxEntries
+		}
+	}
+	return cache
+}
+
+// SetClock 设置判断过期使用的时钟
+func (c *ModelCache) SetClock(now func() time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// RememberModel 读取缓存，未命中时调用 load 加载并写入缓存
+// cache 为 nil 时直接调用 load；返回的切片和映射与缓存共享，调用方不能修改
+func RememberModel[T any](cache *ModelCache, table, key string, load func() (T, error)) (T, error) {
+	value, err := cache.get(table, key, func() (interface{}, error) {
+		return load()
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return value.(T), nil
+}
+
+// get 读取缓存，未命中时合并同一个键的并发加载
+func (c *ModelCache) get(table, key string, load func() (interface{}, error)) (interface{}, error) {
+	if c == nil {
+		return load()
+	}
+
+	c.mu.Lock()
+	t := c.table(table)
+	if entry, ok := t.entries[key]; ok && c.now().Before(entry.expiresAt) {
+		t.stats.Hits++
+		c.mu.Unlock()
+		return entry.value, nil
+	}
+	t.stats.Misses++
+	flightKey := table + "\x00" + key
+	if flight, ok := c.flights[flightKey]; ok {
+		t.stats.Shared++
+		c.mu.Unlock()
+		<-flight.done
+		return flight.value, flight.err
+	}
+	flight := &modelCacheFlight{done: make(chan struct{}), err: fmt.Errorf("加载缓存 %s/%s 时发生panic", table, key)}
+	c.flights[flightKey] = flight
+	generation := t.generation
+	t.stats.Loads++
+	c.mu.Unlock()
+
+	// 加载函数 panic 时等待的请求返回错误，panic 继续向上传递
+	defer func() {
+		c.mu.Lock()
+		delete(c.flights, flightKey)
+		if flight.err != nil {
+			t.stats.Errors++
+		} else if t.generation == generation {
+			c.storeLocked(t, key, flight.value)
+		}
+		c.mu.Unlock()
+		close(flight.done)
+	}()
+	flight.value, flight.err = load()
+	return flight.value, flight.err
+}
+
+// table 获取表的缓存，调用方需持有锁
+func (c *ModelCache) table(name string) *modelCacheTable {
+	t, ok := c.tables[name]
+	if !ok {
+		t = &modelCacheTable{entries: make(map[string]modelCacheEntry), stats: ModelCacheStats{Table: name}}
+		c.tables[name] = t
+	}
+	return t
+}
+
+// storeLocked 写入缓存条目，条目数达到上限时先清理过期条目，仍然已满则淘汰最早过期的条目
+func (c *ModelCache) storeLocked(t *modelCacheTable, key string, value interface{}) {
+	now := c.now()
+	if _, exists := t.entries[key]; !exists && c.size >= c.maxEntries {
+		for _, other := range c.tables {
+			for k, entry := range other.entries {
+				if !now.Before(entry.expiresAt) {
+					delete(other.entries, k)
+					other.stats.Evictions++
+					c.size--
+				}
+			}
+		}
+		for c.size >= c.maxEntries {
+			var oldestTable *modelCacheTable
+			var oldestKey string
+			for _, other := range c.tables {
+				for k, entry := range other.entries {
+					if oldestTable == nil || entry.expiresAt.Before(oldestTable.entries[oldestKey].expiresAt) {
+						oldestTable, oldestKey = other, k
+					}
+				}
+			}
+			delete(oldestTable.entries, oldestKey)
+			oldestTable.stats.Evictions++
+			c.size--
+		}
+	}
+	if _, exists := t.entries[key]; !exists {
+		c.size++
+	}
+	t.entries[key] = modelCacheEntry{value: value, expiresAt: now.Add(c.ttl)}
+}
+
+// Invalidate 使表的全部缓存失效
+func (c *ModelCache) Invalidate(table string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t, ok := c.tables[table]; ok {
+		c.invalidateLocked(t)
+	}
+}
+
+// InvalidateAll 使全部缓存失效
+func (c *ModelCache) InvalidateAll() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, t := range c.tables {
+		c.invalidateLocked(t)
+	}
+}
+
+// invalidateLocked 清空表的缓存并递增版本，调用方需持有锁
+func (c *ModelCache) invalidateLocked(t *modelCacheTable) {
+	c.size -= len(t.entries)
+	t.entries = make(map[string]modelCacheEntry)
+	t.generation++
+	t.stats.Invalidations++
+}
+
+// Stats 按表名排序的缓存统计
+func (c *ModelCache) Stats() []ModelCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := make([]ModelCacheStats, 0, len(c.tables))
+	for _, t := range c.tables {
+		s := t.stats
+		s.Entries = len(t.entries)
+		if total := s.Hits + s.Misses; total > 0 {
+			s.HitRate = float64(s.Hits) / float64(total) * 100
+		}
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Table < stats[j].Table })
+	return stats
+}
+
+// Collector 监控指标采集器
+// 输出 model_cache_hit_rate（两次采集之间的命中率，期间没有读取时不输出）、model_cache_entries，
+// 以及每个表的 model_cache_<表名>_hit_rate、_hits_total、_misses_total、_loads_total、_invalidations_total、_entries
+func (c *ModelCache) Collector() MetricCollector {
+	var mu sync.Mutex
+	var lastHits, lastMisses int64
+	return NewMetricCollector("model_cache", func(ctx context.Context) (map[string]float64, error) {
+		values := make(map[string]float64)
+		var hits, misses int64
+		var entries int
+		for _, s := range c.Stats() {
+			prefix := "model_cache_" + s.Table + "_"
+			values[prefix+"hits_total"] = float64(s.Hits)
+			values[prefix+"misses_total"] = float64(s.Misses)
+			values[prefix+"loads_total"] = float64(s.Loads)
+			values[prefix+"invalidations_total"] = float64(s.Invalidations)
+			values[prefix+"entries"] = float64(s.Entries)
+			if s.Hits+s.Misses > 0 {
+				values[prefix+"hit_rate"] = s.HitRate
+			}
+			hits += s.Hits
+			misses += s.Misses
+			entries += s.Entries
+		}
+		values["model_cache_entries"] = float64(entries)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if deltaHits, deltaMisses := hits-lastHits, misses-lastMisses; deltaHits+deltaMisses > 0 {
+			values["model_cache_hit_rate"] = float64(deltaHits) / float64(deltaHits+deltaMisses) * 100
+		}
+		lastHits, lastMisses = hits, misses
+		return values, nil
+	})
+}
+
+// InstallGormCallbacks 在创建、更新、删除提交后和执行原生SQL后使写入的表失效
+// 原生SQL无法确定表名，使全部缓存失效
+func (c *ModelCache) InstallGormCallbacks(db *gorm.DB) error {
+	invalidate := func(tx *gorm.DB) {
+		if tx.Statement.Table == "" {
+			c.InvalidateAll()
+			return
+		}
+		c.Invalidate(tx.Statement.Table)
+	}
+	callbacks := db.Callback()
+	for name, err := range map[string]error{
+		"create": callbacks.Create().After("gorm:commit_or_rollback_transaction").Register("model_cache:create", invalidate),
+		"update": callbacks.Update().After("gorm:commit_or_rollback_transaction").Register("model_cache:update", invalidate),
+		"delete": callbacks.Delete().After("gorm:commit_or_rollback_transaction").Register("model_cache:delete", invalidate),
+		"raw":    callbacks.Raw().After("gorm:raw").Register("model_cache:raw", invalidate),
+	} {
+		if err != nil {
+			return fmt.Errorf("注册 %s 缓存失效回调失败: %v", name, err)
+		}
+	}
+	return nil
+}
+
+var (
+	defaultModelCache   *ModelCache
+	defaultModelCacheMu sync.RWMutex
+)
+
+// SetDefaultModelCache 设置全局模型缓存
+func SetDefaultModelCache(cache *ModelCache) {
+	defaultModelCacheMu.Lock()
+	defer defaultModelCacheMu.Unlock()
+	defaultModelCache = cache
+}
+
+// DefaultModelCache 获取全局模型缓存，未启用时返回 nil
+func DefaultModelCache() *ModelCache {
+	defaultModelCacheMu.RLock()
+	defer defaultModelCacheMu.RUnlock()
+	return defaultModelCache
+}
+
+// FindUserCached 按ID读取用户，启用模型缓存时先读缓存
+// 返回的用户包含密码哈希，响应前需要清除
+func FindUserCached(db *gorm.DB, id uint) (Models.User, error) {
+	return rememberModelDebug(db.Statement.Context, DefaultModelCache(), usersTable, fmt.Sprintf("id:%d", id), func() (Models.User, error) {
+		var user Models.User
+		err := db.First(&user, id).Error
+		return user, err
+	})
+}
+
+// FindAlertRulesCached 按ID顺序读取全部告警规则，启用模型缓存时先读缓存
+func FindAlertRulesCached(db *gorm.DB) ([]Models.AlertRule, error) {
+	rules, err := rememberModelDebug(db.Statement.Context, DefaultModelCache(), Models.AlertRule{}.TableName(), "all", func() ([]Models.AlertRule, error) {
+		var rules []Models.AlertRule
+		err := db.Order("id").Find(&rules).Error
+		return rules, err
+	})
+	return slices.Clone(rules), err
+}
@@ -0,0 +1,578 @@
+package Services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MonitoringCore 统一监控核心
+// 功能说明：
+// 1. 采集器注册表：各模块注册采集器，由核心统一定期并发采集，也可通过 Observe 直接上报指标
+// 2. 唯一的告警评估引擎：所有告警规则由同一个 AlertService 按最新指标值评估，统一去重、抖动检测和恢复
+// 3. 唯一的通知管道：规则告警和事件告警都通过同一个 NotificationPipeline 发送
+// 4. 实时事件流：每轮采集的指标值和告警状态变化发布到 MonitoringStream，供SSE推送
+// 5. 最近采样缓冲：每轮采集和直接上报的指标写入环形缓冲区，仪表板和健康检查优先从内存读取
+// 6. OptimizedMonitoringService、MonitoringIntegrationService 保留为兼容外观，内部委托给监控核心
+type MonitoringCore struct {
+	mu               sync.RWMutex
+	collectors       map[string]*registeredCollector
+	order            []string
+	collectorTimeout time.Duration
+	values           map[string]float64
+	collected        time.Time
+	recent           *MetricRingBuffer
+
+	// AlertService 不是并发安全的，所有调用都经过 alertMu
+	alertMu  sync.Mutex
+	alerts   *AlertService
+	pipeline *NotificationPipeline
+	stream   *MonitoringStream
+
+	// 告警状态变化监听，由 alertMu 保护
+	alertListeners []func(alert Alert, transition string)
+}
+
+// registeredCollector 已注册的采集器及其运行状态，字段由 MonitoringCore.mu 保护
+type registeredCollector struct {
+	collector    MetricCollector
+	options      CollectorOptions
+	running      bool
+	lastRun      time.Time
+	lastDuration time.Duration
+	lastError    string
+	failures     int64
+}
+
+// due 本轮是否需要执行，上一次采集仍未返回时跳过
+func (r *registeredCollector) due(now time.Time) bool {
+	if r.running {
+		return false
+	}
+	return r.options.Interval <= 0 || r.lastRun.IsZero() || now.Sub(r.lastRun) >= r.options.Interval
+}
+
+// defaultCollectorTimeout 采集器未设置超时时的默认单次采集超时
+const defaultCollectorTimeout = 10 * time.Second
+
+// NewMonitoringCore 创建监控核心，默认注册运行时指标采集器
+func NewMonitoringCore() *MonitoringCore {
+	core := &MonitoringCore{
+		collectors:       make(map[string]*registeredCollector),
+		collectorTimeout: defaultCollectorTimeout,
+		values:           make(map[string]float64),
+		recent:           NewMetricRingBuffer(0),
+		alerts:           NewAlertService(nil, nil),
+		pipeline:         NewNotificationPipeline(),
+		stream:           NewMonitoringStream(),
+	}
+	core.alerts.SetMetricProvider(core.MetricValue)
+	core.alerts.SetNotificationPipeline(core.pipeline)
+	core.alerts.SetTransitionListener(core.alertTransition)
+	if err := core.RegisterCollector(NewRuntimeMetricsCollector()); err != nil {
+		log.Printf("注册运行时指标采集器失败: %v", err)
+	}
+	return core
+}
+
+var (
+	defaultMonitoringCore   *MonitoringCore
+	defaultMonitoringCoreMu sync.Mutex
+)
+
+// SetDefaultMonitoringCore 设置全局监控核心
+func SetDefaultMonitoringCore(core *MonitoringCore) {
+	defaultMonitoringCoreMu.Lock()
+	defer defaultMonitoringCoreMu.Unlock()
+	defaultMonitoringCore = core
+}
+
+// DefaultMonitoringCore 获取全局监控核心，未设置时创建
+func DefaultMonitoringCore() *MonitoringCore {
+	defaultMonitoringCoreMu.Lock()
+	defer defaultMonitoringCoreMu.Unlock()
+	if defaultMonitoringCore == nil {
+		defaultMonitoringCore = NewMonitoringCore()
+	}
+	return defaultMonitoringCore
+}
+
+// SetDefaultCollectorTimeout 设置采集器默认单次采集超时，对未单独设置超时的采集器生效
+func (m *MonitoringCore) SetDefaultCollectorTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.collectorTimeout = timeout
+}
+
+// RegisterCollector 使用默认选项注册采集器，每轮采集都执行
+func (m *MonitoringCore) RegisterCollector(collector MetricCollector) error {
+	return m.RegisterCollectorWithOptions(collector, CollectorOptions{})
+}
+
+// RegisterCollectorWithOptions 注册采集器
+// 功能说明：
+// 1. 采集器实现 CollectorInitializer 时先调用 Init，失败则不注册并返回错误
+// 2. 已有同名采集器时替换并保持原注册顺序，被替换的采集器实现 CollectorCloser 时调用 Close
+func (m *MonitoringCore) RegisterCollectorWithOptions(collector MetricCollector, options CollectorOptions) error {
+	if collector == nil || collector.Name() == "" {
+		return fmt.Errorf("采集器名称不能为空")
+	}
+	if options.Interval < 0 || options.Timeout < 0 {
+		return fmt.Errorf("采集器 %s 的采集间隔和超时不能为负数", collector.Name())
+	}
+
+	m.mu.RLock()
+	timeout := m.collectorTimeout
+	m.mu.RUnlock()
+	if options.Timeout > 0 {
+		timeout = options.Timeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	err := safeInit(ctx, collector)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("采集器 %s 初始化失败: %w", collector.Name(), err)
+	}
+
+	name := collector.Name()
+	m.mu.Lock()
+	previous, exists := m.collectors[name]
+	if !exists {
+		m.order = append(m.order, name)
+	}
+	m.collectors[name] = &registeredCollector{collector: collector, options: options}
+	m.mu.Unlock()
+
+	if exists {
+		closeCollector(previous.collector)
+	}
+	return nil
+}
+
+// UnregisterCollector 注销采集器并调用其 Close，已采集的指标值保留
+func (m *MonitoringCore) UnregisterCollector(name string) {
+	m.mu.Lock()
+	registered, exists := m.collectors[name]
+	if !exists {
+		m.mu.Unlock()
+		return
+	}
+	delete(m.collectors, name)
+	for i, existing := range m.order {
+		if existing == name {
+			m.order = append(m.order[:i], m.order[i+1:]...)
+			break
+		}
+	}
+	m.mu.Unlock()
+
+	closeCollector(registered.collector)
+}
+
+// Close 注销全部采集器并调用其 Close，应用退出时调用
+func (m *MonitoringCore) Close() {
+	m.mu.Lock()
+	collectors := make([]MetricCollector, 0, len(m.order))
+	for _, name := range m.order {
+		collectors = append(collectors, m.collectors[name].collector)
+	}
+	m.collectors = make(map[string]*registeredCollector)
+	m.order = nil
+	m.mu.Unlock()
+
+	for _, collector := range collectors {
+		closeCollector(collector)
+	}
+}
+
+// closeCollector 关闭采集器，失败只记录日志
+func closeCollector(collector MetricCollector) {
+	if err := safeClose(collector); err != nil {
+		log.Printf("关闭指标采集器失败: collector=%s, error=%v", collector.Name(), err)
+	}
+}
+
+// Collectors 返回已注册的采集器名称，按注册顺序
+func (m *MonitoringCore) Collectors() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return append([]string(nil), m.order...)
+}
+
+// CollectorStatuses 返回采集器运行状态，按注册顺序
+func (m *MonitoringCore) CollectorStatuses() []CollectorStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	statuses := make([]CollectorStatus, 0, len(m.order))
+	for _, name := range m.order {
+		registered := m.collectors[name]
+		timeout := registered.options.Timeout
+		if timeout <= 0 {
+			timeout = m.collectorTimeout
+		}
+		statuses = append(statuses, CollectorStatus{
+			Name:         name,
+			Interval:     registered.options.Interval,
+			Timeout:      timeout,
+			LastRunAt:    registered.lastRun,
+			LastDuration: registered.lastDuration,
+			LastError:    registered.lastError,
+			Failures:     registered.failures,
+		})
+	}
+	return statuses
+}
+
+// Collect 并发执行本轮到期的采集器并返回最新指标值
+// 功能说明：
+// 1. 未到采集间隔的采集器跳过，上一次采集超时后仍未返回的采集器也跳过，避免协程堆积
+// 2. 单个采集器失败、超时或 panic 只记录日志和状态，不影响其他采集器
+// 3. 同名指标以注册顺序靠后的采集器为准
+func (m *MonitoringCore) Collect() map[string]float64 {
+	now := time.Now()
+	m.mu.Lock()
+	due := make([]*registeredCollector, 0, len(m.order))
+	for _, name := range m.order {
+		registered := m.collectors[name]
+		if registered.due(now) {
+			registered.running = true
+			registered.lastRun = now
+			due = append(due, registered)
+		}
+	}
+	defaultTimeout := m.collectorTimeout
+	m.mu.Unlock()
+
+	results := make([]map[string]float64, len(due))
+	var wg sync.WaitGroup
+	for i, registered := range due {
+		timeout := registered.options.Timeout
+		if timeout <= 0 {
+			timeout = defaultTimeout
+		}
+		wg.Add(1)
+		go func(i int, registered *registeredCollector, timeout time.Duration) {
+			defer wg.Done()
+			results[i] = m.runCollector(registered, timeout)
+		}(i, registered, timeout)
+	}
+	wg.Wait()
+
+	m.mu.Lock()
+	m.collected = time.Now()
+	for _, values := range results {
+		for name, value := range values {
+			m.values[name] = value
+		}
+		// 只缓冲本轮实际采集到的值，未到期或失败的采集器不产生新采样
+		m.recent.RecordValues(values, m.collected)
+	}
+	m.mu.Unlock()
+
+	values := m.Values()
+	m.stream.PublishMetrics(values)
+	return values
+}
+
+// runCollector 在超时控制下执行一次采集并记录运行状态，超时或失败时返回 nil
+func (m *MonitoringCore) runCollector(registered *registeredCollector, timeout time.Duration) map[string]float64 {
+	type collectResult struct {
+		values map[string]float64
+		err    error
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	started := time.Now()
+	// 结果通道带缓冲，超时后采集协程返回时不会阻塞
+	done := make(chan collectResult, 1)
+	go func() {
+		values, err := safeCollect(ctx, registered.collector)
+		m.mu.Lock()
+		registered.running = false
+		m.mu.Unlock()
+		done <- collectResult{values: values, err: err}
+	}()
+
+	var result collectResult
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		result.err = fmt.Errorf("采集超时（%s）", timeout)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	registered.lastDuration = time.Since(started)
+	if result.err != nil {
+		registered.lastError = result.err.Error()
+		registered.failures++
+		log.Printf("指标采集失败: collector=%s, error=%v", registered.collector.Name(), result.err)
+		return nil
+	}
+	registered.lastError = ""
+	registered.failures = 0
+	return result.values
+}
+
+// Observe 直接上报指标值，供推送型数据源使用
+func (m *MonitoringCore) Observe(name string, value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[name] = value
+	m.recent.Record(name, value, time.Now())
+}
+
+// MetricValue 获取指标最新值，作为告警引擎的指标来源
+func (m *MonitoringCore) MetricValue(name string) (float64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	value, exists := m.values[name]
+	if !exists {
+		return 0, fmt.Errorf("未知指标: %s", name)
+	}
+	return value, nil
+}
+
+// Values 返回全部指标最新值
+func (m *MonitoringCore) Values() map[string]float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	values := make(map[string]float64, len(m.values))
+	for name, value := range m.values {
+		values[name] = value
+	}
+	return values
+}
+
+// SetRecentBufferSize 设置每个指标保留的最近采样数，已缓冲的采样会被丢弃
+func (m *MonitoringCore) SetRecentBufferSize(size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if size == m.recent.Size() {
+		return
+	}
+	m.recent = NewMetricRingBuffer(size)
+}
+
+// Recent 返回最近采样缓冲区
+func (m *MonitoringCore) Recent() *MetricRingBuffer {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.recent
+}
+
+// Pipeline 返回通知管道
+func (m *MonitoringCore) Pipeline() *NotificationPipeline {
+	return m.pipeline
+}
+
+// Stream 返回实时监控事件流
+func (m *MonitoringCore) Stream() *MonitoringStream {
+	return m.stream
+}
+
+// AddAlertListener 添加告警状态变化监听，告警触发和解决时在评估过程中同步调用
+func (m *MonitoringCore) AddAlertListener(listener func(alert Alert, transition string)) {
+	m.alertMu.Lock()
+	defer m.alertMu.Unlock()
+	m.alertListeners = append(m.alertListeners, listener)
+}
+
+// alertTransition 发布告警状态变化到事件流并通知监听，调用方持有 alertMu
+func (m *MonitoringCore) alertTransition(alert Alert, transition string) {
+	m.stream.PublishAlert(alert, transition)
+	for _, listener := range m.alertListeners {
+		listener(alert, transition)
+	}
+}
+
+// AlertEngine 返回告警评估引擎
+// 引擎不是并发安全的，评估期间的调用应使用监控核心的方法
+func (m *MonitoringCore) AlertEngine() *AlertService {
+	return m.alerts
+}
+
+// AddRule 添加告警规则
+func (m *MonitoringCore) AddRule(rule *AlertRule) error {
+	m.alertMu.Lock()
+	defer m.alertMu.Unlock()
+	return m.alerts.AddRule(rule)
+}
+
+// RemoveRule 移除告警规则
+func (m *MonitoringCore) RemoveRule(ruleID string) {
+	m.alertMu.Lock()
+	defer m.alertMu.Unlock()
+	m.alerts.RemoveRule(ruleID)
+}
+
+// SetThreshold 设置单指标固定阈值规则，同一指标只保留一条，供兼容外观使用
+func (m *MonitoringCore) SetThreshold(metric, condition string, threshold float64, level AlertLevel) error {
+	return m.AddRule(&AlertRule{
+		ID:        "threshold_" + metric,
+		Name:      fmt.Sprintf("%s 超过阈值", metric),
+		Metric:    metric,
+		Condition: condition,
+		Threshold: threshold,
+		Level:     level,
+		Enabled:   true,
+	})
+}
+
+// SetMetricHistory 设置指标历史，告警引擎的动态阈值规则和回测需要
+func (m *MonitoringCore) SetMetricHistory(history *MetricHistoryService) {
+	m.alertMu.Lock()
+	defer m.alertMu.Unlock()
+	m.alerts.SetMetricHistory(history)
+}
+
+// Rules 返回全部告警规则
+func (m *MonitoringCore) Rules() []*AlertRule {
+	m.alertMu.Lock()
+	defer m.alertMu.Unlock()
+
+	rules := m.alerts.GetRules()
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules
+}
+
+// CheckAlerts 使用当前指标值评估告警规则，不执行采集
+func (m *MonitoringCore) CheckAlerts() error {
+	m.alertMu.Lock()
+	defer m.alertMu.Unlock()
+	return m.alerts.CheckAlerts()
+}
+
+// Evaluate 执行一轮采集并评估告警规则
+func (m *MonitoringCore) Evaluate() error {
+	m.Collect()
+	return m.CheckAlerts()
+}
+
+// Alerts 获取告警列表，按创建时间倒序
+// status 为空时不过滤状态，limit 不大于0时返回全部
+func (m *MonitoringCore) Alerts(status string, limit int) []*Alert {
+	m.alertMu.Lock()
+	alerts := m.alerts.GetAlerts(status, len(m.alerts.alerts))
+	m.alertMu.Unlock()
+
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].CreatedAt.After(alerts[j].CreatedAt) })
+	if limit > 0 && len(alerts) > limit {
+		alerts = alerts[:limit]
+	}
+	return alerts
+}
+
+// AlertHistory 获取告警历史，转换为通知通道使用的告警信息
+func (m *MonitoringCore) AlertHistory(limit int) []MonitoringAlert {
+	alerts := m.Alerts("", limit)
+
+	m.alertMu.Lock()
+	defer m.alertMu.Unlock()
+	history := make([]MonitoringAlert, 0, len(alerts))
+	for _, alert := range alerts {
+		rule, exists := m.alerts.rules[alert.RuleID]
+		if !exists {
+			rule = &AlertRule{ID: alert.RuleID, Name: alert.RuleID}
+			if alert.Source != "" {
+				rule.Name = alert.Metric
+			}
+		}
+		history = append(history, m.alerts.monitoringAlert(alert, rule))
+	}
+	return history
+}
+
+// ReceiveExternalAlert 接收外部监控系统推送的告警，与规则告警共用去重、通知和状态变化监听
+func (m *MonitoringCore) ReceiveExternalAlert(alert ExternalAlert) (*Alert, string) {
+	m.alertMu.Lock()
+	defer m.alertMu.Unlock()
+	return m.alerts.ReceiveExternal(alert)
+}
+
+// Notify 通过通知管道异步发送事件告警，不经过规则评估
+func (m *MonitoringCore) Notify(alert MonitoringAlert) {
+	m.pipeline.Publish(alert)
+}
+
+// Stats 返回监控核心统计
+func (m *MonitoringCore) Stats() map[string]interface{} {
+	m.alertMu.Lock()
+	stats := m.alerts.GetAlertStats()
+	m.alertMu.Unlock()
+
+	m.mu.RLock()
+	stats["collectors"] = append([]string(nil), m.order...)
+	stats["metrics"] = len(m.values)
+	stats["last_collected_at"] = m.collected
+	stats["recent_buffer"] = map[string]interface{}{"size": m.recent.Size(), "metrics": m.recent.Metrics()}
+	m.mu.RUnlock()
+
+	channels := make([]string, 0)
+	for _, channel := range m.pipeline.Channels() {
+		channels = append(channels, channel.GetName())
+	}
+	stats["notification_channels"] = channels
+	stats["collector_status"] = m.CollectorStatuses()
+	stats["stream"] = m.stream.Stats()
+	return stats
+}
+
+// Start 按固定间隔执行采集和告警评估，ctx 取消后停止
+func (m *MonitoringCore) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := m.Evaluate(); err != nil {
+					log.Printf("告警评估失败: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// NewRuntimeMetricsCollector 创建Go运行时指标采集器
+// 指标：cpu_usage、memory_usage（百分比）、goroutines、heap_size（字节）、gc_count
+func NewRuntimeMetricsCollector() MetricCollector {
+	return NewMetricCollector("runtime", func(ctx context.Context) (map[string]float64, error) {
+		// 注意：ReadMemStats 是STW操作，不要过于频繁调用
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+
+		// 基于GC次数的简化CPU使用率估算，实际应用中应使用专门的CPU监控库
+		cpuUsage := float64(m.NumGC) * 0.1
+		// 按1GB总内存计算内存使用率
+		memoryUsage := float64(m.HeapAlloc) / float64(1024*1024*1024) * 100
+
+		return map[string]float64{
+			"cpu_usage":    cpuUsage,
+			"memory_usage": memoryUsage,
+			"goroutines":   float64(runtime.NumGoroutine()),
+			"heap_size":    float64(m.HeapAlloc),
+			"gc_count":     float64(m.NumGC),
+		}, nil
+	})
+}
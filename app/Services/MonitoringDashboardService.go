@@ -0,0 +1,895 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrDashboardNotFound 仪表板不存在或当前用户不可见
+	ErrDashboardNotFound = errors.New("仪表板不存在")
+	// ErrWidgetNotFound 组件不存在
+	ErrWidgetNotFound = errors.New("组件不存在")
+	// ErrDashboardForbidden 当前用户无权修改仪表板
+	ErrDashboardForbidden = errors.New("无权修改该仪表板")
+	// ErrDashboardNameExists 仪表板名称已存在
+	ErrDashboardNameExists = errors.New("仪表板名称已存在")
+	// ErrInvalidDashboard 仪表板或组件配置无效
+	ErrInvalidDashboard = errors.New("仪表板配置无效")
+)
+
+// 组件数据源
+const (
+	WidgetDataSourceMetric        = "metric"         // 监控核心的最新指标值
+	WidgetDataSourceMetricHistory = "metric_history" // 指标历史时间序列
+	WidgetDataSourceAlerts        = "alerts"         // 告警列表
+)
+
+// dashboardExportVersion 仪表板导出格式版本
+const dashboardExportVersion = 1
+
+// maxWidgetHistoryRange 历史指标组件的最大查询范围
+const maxWidgetHistoryRange = 30 * 24 * time.Hour
+
+// maxWidgetHistoryPoints 历史指标组件单个序列的最大点数，超过时自动增大降采样间隔
+const maxWidgetHistoryPoints = 500
+
+var (
+	widgetTypes       = []string{"line", "area", "bar", "gauge", "stat", "table", "text", "alert"}
+	widgetDataSources = []string{WidgetDataSourceMetric, WidgetDataSourceMetricHistory, WidgetDataSourceAlerts}
+	widgetAggregation = []string{"avg", "max", "min", "sum", "last"}
+)
+
+// DashboardViewer 访问仪表板、告警规则等监控数据的用户
+type DashboardViewer struct {
+	UserID uint
+	Role   string
+	Teams  map[uint]string // 所在团队及角色，由 TeamService.Memberships 获取
+}
+
+func (v DashboardViewer) isAdmin() bool {
+	return v.Role == "admin"
+}
+
+// inTeam 用户是否为团队成员
+func (v DashboardViewer) inTeam(teamID *uint) bool {
+	if teamID == nil {
+		return false
+	}
+	_, ok := v.Teams[*teamID]
+	return ok
+}
+
+// MonitoringDashboardInput 创建、更新和导入仪表板的参数
+type MonitoringDashboardInput struct {
+	Name            string          `json:"name" binding:"required,max=100"`
+	Description     string          `json:"description" binding:"max=500"`
+	Layout          json.RawMessage `json:"layout" swaggertype:"object"` // 布局配置，如 {"columns":12}
+	RefreshInterval int             `json:"refresh_interval"`            // 刷新间隔（秒），为0时默认30
+	IsDefault       bool            `json:"is_default"`                  // 设为默认仪表板，仅管理员
+	IsPublic        bool            `json:"is_public"`                   // 所有登录用户可见
+	SharedRoles     []string        `json:"shared_roles"`                // 可查看的角色
+	TeamID          *uint           `json:"team_id,omitempty"`           // 所属团队，只能指定自己所在的团队，为0时取消归属，不传时不修改；导出时不包含
+}
+
+// WidgetQuery 组件查询
+// metric 数据源使用 Metrics；metric_history 使用 Metrics、Range、Step、Aggregation；alerts 使用 Status、Limit
+type WidgetQuery struct {
+	Metrics     []string `json:"metrics,omitempty"`
+	Range       string   `json:"range,omitempty"`       // 查询范围，默认1h，最长30天
+	Step        string   `json:"step,omitempty"`        // 降采样间隔，为空时按范围自动计算
+	Aggregation string   `json:"aggregation,omitempty"` // 降采样聚合方式：avg（默认）、max、min、sum、last
+	Status      string   `json:"status,omitempty"`      // 告警状态
+	Limit       int      `json:"limit,omitempty"`       // 告警数量，默认20
+}
+
+// WidgetThreshold 组件阈值，指标值不小于 Value 时状态为 Level
+type WidgetThreshold struct {
+	Value float64 `json:"value"`
+	Level string  `json:"level"`
+	Color string  `json:"color,omitempty"`
+}
+
+// WidgetPosition 组件在网格中的位置
+type WidgetPosition struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// WidgetSize 组件在网格中的大小
+type WidgetSize struct {
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+// MonitoringWidgetInput 创建、更新和导入组件的参数
+type MonitoringWidgetInput struct {
+	Name            string            `json:"name" binding:"required,max=100"`
+	Type            string            `json:"type" binding:"required"`
+	DataSource      string            `json:"data_source"`
+	Query           *WidgetQuery      `json:"query"`
+	Thresholds      []WidgetThreshold `json:"thresholds"`
+	Position        WidgetPosition    `json:"position"`
+	Size            WidgetSize        `json:"size"`
+	Config          json.RawMessage   `json:"config" swaggertype:"object"` // 图表展示配置，如坐标轴、单位
+	RefreshInterval int               `json:"refresh_interval"`
+	Enabled         *bool             `json:"enabled"`
+}
+
+// MonitoringDashboardExport 仪表板导出格式
+type MonitoringDashboardExport struct {
+	Version    int                      `json:"version"`
+	ExportedAt time.Time                `json:"exported_at"`
+	Dashboard  MonitoringDashboardInput `json:"dashboard"`
+	Widgets    []MonitoringWidgetInput  `json:"widgets"`
+}
+
+// WidgetSeriesPoint 时间序列数据点
+type WidgetSeriesPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// WidgetSeries 时间序列
+type WidgetSeries struct {
+	Metric string              `json:"metric"`
+	Points []WidgetSeriesPoint `json:"points"`
+}
+
+// WidgetData 组件数据
+type WidgetData struct {
+	WidgetID    uint               `json:"widget_id"`
+	Type        string             `json:"type"`
+	DataSource  string             `json:"data_source"`
+	Values      map[string]float64 `json:"values,omitempty"` // 各指标的最新值
+	States      map[string]string  `json:"states,omitempty"` // 按阈值计算的状态，未达到任何阈值时为 normal
+	Series      []WidgetSeries     `json:"series,omitempty"`
+	Alerts      []*Alert           `json:"alerts,omitempty"`
+	Freshness   *MetricFreshness   `json:"freshness,omitempty"` // 指标数据的来源和新鲜度，告警组件为空
+	GeneratedAt time.Time          `json:"generated_at"`
+}
+
+// MonitoringDashboardService 监控仪表板服务
+// 功能说明：
+// 1. 仪表板和组件的增删改查，组件保存图表类型、查询、阈值和布局
+// 2. 可见性：管理员和创建者始终可见，公开仪表板所有登录用户可见，否则只对共享的角色和所属团队的成员可见；创建者、管理员和所属团队的成员可以修改
+// 3. 在服务端执行组件查询：最新指标值和时间序列优先读取监控核心的最近采样缓冲区，缓冲区未覆盖时回退到指标历史，告警来自告警引擎
+// 4. 仪表板及其组件可以导出为JSON并在其他环境导入
+type MonitoringDashboardService struct {
+	db         *gorm.DB
+	core       *MonitoringCore
+	history    *MetricHistoryService
+	staleAfter time.Duration
+}
+
+// NewMonitoringDashboardService 创建监控仪表板服务
+func NewMonitoringDashboardService(db *gorm.DB) *MonitoringDashboardService {
+	return &MonitoringDashboardService{db: db, core: DefaultMonitoringCore(), staleAfter: defaultMetricStaleAfter}
+}
+
+// SetMonitoringCore 设置组件查询使用的监控核心
+func (s *MonitoringDashboardService) SetMonitoringCore(core *MonitoringCore) {
+	s.core = core
+}
+
+// SetMetricHistory 设置指标历史，未设置时 metric_history 组件不可用
+func (s *MonitoringDashboardService) SetMetricHistory(history *MetricHistoryService) {
+	s.history = history
+}
+
+// SetStaleAfter 设置组件数据的过期时间，不大于0时使用默认值
+func (s *MonitoringDashboardService) SetStaleAfter(staleAfter time.Duration) {
+	if staleAfter <= 0 {
+		staleAfter = defaultMetricStaleAfter
+	}
+	s.staleAfter = staleAfter
+}
+
+// ListDashboards 获取当前用户可见的仪表板，默认仪表板在前
+func (s *MonitoringDashboardService) ListDashboards(viewer DashboardViewer) ([]Models.MonitoringDashboard, error) {
+	var dashboards []Models.MonitoringDashboard
+	if err := s.db.Order("is_default DESC, name ASC").Find(&dashboards).Error; err != nil {
+		return nil, err
+	}
+
+	visible := make([]Models.MonitoringDashboard, 0, len(dashboards))
+	for _, dashboard := range dashboards {
+		if dashboardVisible(&dashboard, viewer) {
+			visible = append(visible, dashboard)
+		}
+	}
+	return visible, nil
+}
+
+// GetDashboard 获取仪表板及其组件，不可见时返回 ErrDashboardNotFound
+func (s *MonitoringDashboardService) GetDashboard(id uint, viewer DashboardViewer) (*Models.MonitoringDashboard, error) {
+	var dashboard Models.MonitoringDashboard
+	err := s.db.Preload("Widgets", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).First(&dashboard, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDashboardNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !dashboardVisible(&dashboard, viewer) {
+		return nil, ErrDashboardNotFound
+	}
+	return &dashboard, nil
+}
+
+// CreateDashboard 创建仪表板，当前用户为创建者
+func (s *MonitoringDashboardService) CreateDashboard(input MonitoringDashboardInput, viewer DashboardViewer) (*Models.MonitoringDashboard, error) {
+	dashboard := &Models.MonitoringDashboard{CreatedBy: viewer.UserID}
+	if err := applyDashboardInput(dashboard, input, viewer); err != nil {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensureDashboardNameAvailable(tx, dashboard.Name, 0); err != nil {
+			return err
+		}
+		if err := tx.Create(dashboard).Error; err != nil {
+			return err
+		}
+		return clearOtherDefaultDashboards(tx, dashboard)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dashboard, nil
+}
+
+// UpdateDashboard 更新仪表板属性，不修改组件
+func (s *MonitoringDashboardService) UpdateDashboard(id uint, input MonitoringDashboardInput, viewer DashboardViewer) (*Models.MonitoringDashboard, error) {
+	dashboard, err := s.editableDashboard(id, viewer)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyDashboardInput(dashboard, input, viewer); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensureDashboardNameAvailable(tx, dashboard.Name, dashboard.ID); err != nil {
+			return err
+		}
+		if err := tx.Omit("Widgets").Save(dashboard).Error; err != nil {
+			return err
+		}
+		return clearOtherDefaultDashboards(tx, dashboard)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dashboard, nil
+}
+
+// DeleteDashboard 删除仪表板，仪表板进入回收站
+func (s *MonitoringDashboardService) DeleteDashboard(id uint, viewer DashboardViewer) error {
+	dashboard, err := s.editableDashboard(id, viewer)
+	if err != nil {
+		return err
+	}
+	// 仪表板进入回收站，组件保留以便恢复，彻底删除时一并删除
+	return NewDeletionService(s.db, nil).Delete(TrashDashboards, dashboard.ID)
+}
+
+// CreateWidget 向仪表板添加组件
+func (s *MonitoringDashboardService) CreateWidget(dashboardID uint, input MonitoringWidgetInput, viewer DashboardViewer) (*Models.MonitoringWidget, error) {
+	if _, err := s.editableDashboard(dashboardID, viewer); err != nil {
+		return nil, err
+	}
+
+	widget := &Models.MonitoringWidget{DashboardID: dashboardID}
+	if err := applyWidgetInput(widget, input); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(widget).Error; err != nil {
+		return nil, err
+	}
+	return widget, nil
+}
+
+// UpdateWidget 更新组件
+func (s *MonitoringDashboardService) UpdateWidget(dashboardID, widgetID uint, input MonitoringWidgetInput, viewer DashboardViewer) (*Models.MonitoringWidget, error) {
+	if _, err := s.editableDashboard(dashboardID, viewer); err != nil {
+		return nil, err
+	}
+	widget, err := s.findWidget(dashboardID, widgetID)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyWidgetInput(widget, input); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(widget).Error; err != nil {
+		return nil, err
+	}
+	return widget, nil
+}
+
+// DeleteWidget 删除组件
+func (s *MonitoringDashboardService) DeleteWidget(dashboardID, widgetID uint, viewer DashboardViewer) error {
+	if _, err := s.editableDashboard(dashboardID, viewer); err != nil {
+		return err
+	}
+	widget, err := s.findWidget(dashboardID, widgetID)
+	if err != nil {
+		return err
+	}
+	return s.db.Delete(widget).Error
+}
+
+// WidgetData 在服务端执行组件查询并返回数据
+func (s *MonitoringDashboardService) WidgetData(dashboardID, widgetID uint, viewer DashboardViewer) (*WidgetData, error) {
+	if _, err := s.GetDashboard(dashboardID, viewer); err != nil {
+		return nil, err
+	}
+	widget, err := s.findWidget(dashboardID, widgetID)
+	if err != nil {
+		return nil, err
+	}
+
+	input, err := widgetInput(widget)
+	if err != nil {
+		return nil, err
+	}
+	query := WidgetQuery{}
+	if input.Query != nil {
+		query = *input.Query
+	}
+	// 用户无权查看的指标不返回数据，告警组件只返回可见的告警
+	visibility := DefaultMonitoringVisibility()
+	query.Metrics = visibility.VisibleMetrics(query.Metrics, viewer)
+
+	data := &WidgetData{WidgetID: widget.ID, Type: widget.Type, DataSource: widget.DataSource, GeneratedAt: time.Now()}
+	switch widget.DataSource {
+	case WidgetDataSourceMetric:
+		values, source, updatedAt, err := s.latestValues(query.Metrics)
+		if err != nil {
+			return nil, err
+		}
+		data.Values = values
+		freshness := newMetricFreshness(source, updatedAt, data.GeneratedAt, s.staleAfter)
+		data.Freshness = &freshness
+	case WidgetDataSourceMetricHistory:
+		if s.history == nil {
+			return nil, ErrMetricHistoryUnavailable
+		}
+		if billing := DefaultBillingService(); billing != nil {
+			window, _, err := widgetHistoryWindow(query)
+			if err != nil {
+				return nil, err
+			}
+			if err := billing.RequireHistoryRange(viewer.UserID, viewer.Role, window); err != nil {
+				return nil, err
+			}
+		}
+		series, source, updatedAt, err := s.querySeries(query, data.GeneratedAt)
+		if err != nil {
+			return nil, err
+		}
+		data.Series = series
+		freshness := newMetricFreshness(source, updatedAt, data.GeneratedAt, s.staleAfter)
+		data.Freshness = &freshness
+		data.Values = make(map[string]float64)
+		for _, item := range series {
+			if len(item.Points) > 0 {
+				data.Values[item.Metric] = item.Points[len(item.Points)-1].Value
+			}
+		}
+	case WidgetDataSourceAlerts:
+		limit := query.Limit
+		if limit <= 0 {
+			limit = 20
+		}
+		data.Alerts = filterAlerts(s.core.Alerts(query.Status, 0), visibility.AlertFilter(viewer))
+		if len(data.Alerts) > limit {
+			data.Alerts = data.Alerts[:limit]
+		}
+	}
+
+	if len(input.Thresholds) > 0 && len(data.Values) > 0 {
+		data.States = make(map[string]string, len(data.Values))
+		for metric, value := range data.Values {
+			data.States[metric] = widgetThresholdState(input.Thresholds, value)
+		}
+	}
+	return data, nil
+}
+
+// ExportDashboard 导出仪表板及其组件
+func (s *MonitoringDashboardService) ExportDashboard(id uint, viewer DashboardViewer) (*MonitoringDashboardExport, error) {
+	dashboard, err := s.GetDashboard(id, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &MonitoringDashboardExport{
+		Version:    dashboardExportVersion,
+		ExportedAt: time.Now(),
+		Dashboard: MonitoringDashboardInput{
+			Name:            dashboard.Name,
+			Description:     dashboard.Description,
+			Layout:          json.RawMessage(dashboard.Layout),
+			RefreshInterval: dashboard.RefreshInterval,
+			IsPublic:        dashboard.IsPublic,
+			SharedRoles:     splitNotificationList(dashboard.SharedRoles),
+		},
+		Widgets: make([]MonitoringWidgetInput, 0, len(dashboard.Widgets)),
+	}
+	for i := range dashboard.Widgets {
+		input, err := widgetInput(&dashboard.Widgets[i])
+		if err != nil {
+			return nil, err
+		}
+		export.Widgets = append(export.Widgets, input)
+	}
+	return export, nil
+}
+
+// ImportDashboard 导入仪表板，当前用户为创建者，导入的仪表板不会设为默认
+// name 不为空时使用该名称，用于导入同名仪表板的副本
+func (s *MonitoringDashboardService) ImportDashboard(export MonitoringDashboardExport, name string, viewer DashboardViewer) (*Models.MonitoringDashboard, error) {
+	if export.Version != dashboardExportVersion {
+		return nil, fmt.Errorf("%w：不支持的导出版本 %d", ErrInvalidDashboard, export.Version)
+	}
+
+	input := export.Dashboard
+	input.IsDefault = false
+	if name != "" {
+		input.Name = name
+	}
+	dashboard := &Models.MonitoringDashboard{CreatedBy: viewer.UserID}
+	if err := applyDashboardInput(dashboard, input, viewer); err != nil {
+		return nil, err
+	}
+	for i, widgetInput := range export.Widgets {
+		widget := Models.MonitoringWidget{}
+		if err := applyWidgetInput(&widget, widgetInput); err != nil {
+			return nil, fmt.Errorf("组件 %d: %w", i+1, err)
+		}
+		dashboard.Widgets = append(dashboard.Widgets, widget)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensureDashboardNameAvailable(tx, dashboard.Name, 0); err != nil {
+			return err
+		}
+		return tx.Create(dashboard).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dashboard, nil
+}
+
+// editableDashboard 获取当前用户可修改的仪表板
+func (s *MonitoringDashboardService) editableDashboard(id uint, viewer DashboardViewer) (*Models.MonitoringDashboard, error) {
+	var dashboard Models.MonitoringDashboard
+	err := s.db.First(&dashboard, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDashboardNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !dashboardVisible(&dashboard, viewer) {
+		return nil, ErrDashboardNotFound
+	}
+	if !viewer.isAdmin() && dashboard.CreatedBy != viewer.UserID && !viewer.inTeam(dashboard.TeamID) {
+		return nil, ErrDashboardForbidden
+	}
+	return &dashboard, nil
+}
+
+// findWidget 获取仪表板中的组件
+func (s *MonitoringDashboardService) findWidget(dashboardID, widgetID uint) (*Models.MonitoringWidget, error) {
+	var widget Models.MonitoringWidget
+	err := s.db.Where("dashboard_id = ?", dashboardID).First(&widget, widgetID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWidgetNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &widget, nil
+}
+
+// latestValues 获取指标最新值，以及数据来源和各指标最近采样中最早的时间
+// 优先读取最近采样缓冲区，缓冲区中没有的指标从指标历史查询，有指标回退到指标历史时来源为 database
+func (s *MonitoringDashboardService) latestValues(metrics []string) (map[string]float64, string, time.Time, error) {
+	values := make(map[string]float64, len(metrics))
+	source := MetricSourceNone
+	var updatedAt time.Time
+	add := func(sample Models.MetricSample) {
+		values[sample.Name] = sample.Value
+		if updatedAt.IsZero() || sample.Timestamp.Before(updatedAt) {
+			updatedAt = sample.Timestamp
+		}
+	}
+
+	recent := s.core.Recent()
+	missing := make([]string, 0)
+	for _, metric := range metrics {
+		sample, exists := recent.Latest(metric)
+		if !exists {
+			missing = append(missing, metric)
+			continue
+		}
+		add(sample)
+		source = MetricSourceMemory
+	}
+	if len(missing) == 0 || s.history == nil {
+		return values, source, updatedAt, nil
+	}
+
+	latest, err := s.history.Latest(missing)
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+	for _, sample := range latest {
+		add(sample)
+		source = MetricSourceDatabase
+	}
+	return values, source, updatedAt, nil
+}
+
+// querySeries 查询指标时间序列并按步长降采样，同时返回数据来源和各指标最近采样中最早的时间
+// 最近采样缓冲区覆盖整个查询范围的指标直接从内存读取，否则查询指标历史
+func (s *MonitoringDashboardService) querySeries(query WidgetQuery, now time.Time) ([]WidgetSeries, string, time.Time, error) {
+	window, step, err := widgetHistoryWindow(query)
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+	start := now.Add(-window)
+
+	recent := s.core.Recent()
+	source := MetricSourceNone
+	var updatedAt time.Time
+	series := make([]WidgetSeries, 0, len(query.Metrics))
+	for _, metric := range query.Metrics {
+		samples, covered := recent.Range(metric, start, now)
+		if covered {
+			if source == MetricSourceNone {
+				source = MetricSourceMemory
+			}
+		} else {
+			if samples, err = s.history.Query(metric, start, now); err != nil {
+				return nil, "", time.Time{}, err
+			}
+			if len(samples) > 0 {
+				source = MetricSourceDatabase
+			}
+		}
+		if len(samples) > 0 {
+			if last := samples[len(samples)-1].Timestamp; updatedAt.IsZero() || last.Before(updatedAt) {
+				updatedAt = last
+			}
+		}
+		series = append(series, WidgetSeries{Metric: metric, Points: downsampleWidgetSeries(samples, start, step, query.Aggregation)})
+	}
+	return series, source, updatedAt, nil
+}
+
+// dashboardVisible 仪表板对用户是否可见
+func dashboardVisible(dashboard *Models.MonitoringDashboard, viewer DashboardViewer) bool {
+	if viewer.isAdmin() || dashboard.IsPublic || (viewer.UserID != 0 && dashboard.CreatedBy == viewer.UserID) || viewer.inTeam(dashboard.TeamID) {
+		return true
+	}
+	if viewer.Role == "" {
+		return false
+	}
+	for _, role := range splitNotificationList(dashboard.SharedRoles) {
+		if role == viewer.Role {
+			return true
+		}
+	}
+	return false
+}
+
+// applyDashboardInput 校验参数并写入仪表板
+func applyDashboardInput(dashboard *Models.MonitoringDashboard, input MonitoringDashboardInput, viewer DashboardViewer) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len(name) > 100 {
+		return fmt.Errorf("%w：名称不能为空且不超过100个字符", ErrInvalidDashboard)
+	}
+	if input.IsDefault && !viewer.isAdmin() {
+		return ErrDashboardForbidden
+	}
+	if input.RefreshInterval < 0 {
+		return fmt.Errorf("%w：刷新间隔不能为负数", ErrInvalidDashboard)
+	}
+	if input.TeamID != nil && *input.TeamID != 0 && !viewer.isAdmin() && !viewer.inTeam(input.TeamID) {
+		return ErrDashboardForbidden
+	}
+
+	layout := "{}"
+	if len(input.Layout) > 0 && string(input.Layout) != "null" {
+		if !json.Valid(input.Layout) {
+			return fmt.Errorf("%w：布局配置不是有效的JSON", ErrInvalidDashboard)
+		}
+		layout = string(input.Layout)
+	}
+	roles := make([]string, 0, len(input.SharedRoles))
+	for _, role := range input.SharedRoles {
+		if role = strings.TrimSpace(role); role != "" {
+			roles = append(roles, role)
+		}
+	}
+
+	dashboard.Name = name
+	dashboard.Description = input.Description
+	dashboard.Layout = layout
+	dashboard.RefreshInterval = input.RefreshInterval
+	if dashboard.RefreshInterval == 0 {
+		dashboard.RefreshInterval = 30
+	}
+	dashboard.IsDefault = input.IsDefault
+	dashboard.IsPublic = input.IsPublic
+	dashboard.SharedRoles = strings.Join(roles, ",")
+	if input.TeamID != nil {
+		dashboard.TeamID = input.TeamID
+		if *input.TeamID == 0 {
+			dashboard.TeamID = nil
+		}
+	}
+	return nil
+}
+
+// applyWidgetInput 校验参数并写入组件
+func applyWidgetInput(widget *Models.MonitoringWidget, input MonitoringWidgetInput) error {
+	if strings.TrimSpace(input.Name) == "" {
+		return fmt.Errorf("%w：组件名称不能为空", ErrInvalidDashboard)
+	}
+	if !containsString(widgetTypes, input.Type) {
+		return fmt.Errorf("%w：不支持的组件类型 %q", ErrInvalidDashboard, input.Type)
+	}
+	if err := validateWidgetQuery(input); err != nil {
+		return fmt.Errorf("%w：%v", ErrInvalidDashboard, err)
+	}
+	for _, threshold := range input.Thresholds {
+		if threshold.Level == "" || math.IsNaN(threshold.Value) || math.IsInf(threshold.Value, 0) {
+			return fmt.Errorf("%w：阈值需要有效的数值和级别", ErrInvalidDashboard)
+		}
+	}
+	if input.Position.X < 0 || input.Position.Y < 0 || input.Size.W < 0 || input.Size.H < 0 {
+		return fmt.Errorf("%w：组件位置和大小不能为负数", ErrInvalidDashboard)
+	}
+	config := "{}"
+	if len(input.Config) > 0 && string(input.Config) != "null" {
+		if !json.Valid(input.Config) {
+			return fmt.Errorf("%w：组件配置不是有效的JSON", ErrInvalidDashboard)
+		}
+		config = string(input.Config)
+	}
+
+	thresholds := append([]WidgetThreshold(nil), input.Thresholds...)
+	sort.SliceStable(thresholds, func(i, j int) bool { return thresholds[i].Value < thresholds[j].Value })
+	size := input.Size
+	if size.W == 0 {
+		size.W = 6
+	}
+	if size.H == 0 {
+		size.H = 4
+	}
+
+	widget.Name = strings.TrimSpace(input.Name)
+	widget.Type = input.Type
+	widget.DataSource = input.DataSource
+	widget.Query = marshalWidgetJSON(input.Query)
+	widget.Thresholds = marshalWidgetJSON(thresholds)
+	widget.Position = marshalWidgetJSON(input.Position)
+	widget.Size = marshalWidgetJSON(size)
+	widget.Config = config
+	widget.RefreshInterval = input.RefreshInterval
+	if widget.RefreshInterval <= 0 {
+		widget.RefreshInterval = 30
+	}
+	widget.Enabled = input.Enabled == nil || *input.Enabled
+	return nil
+}
+
+// validateWidgetQuery 按数据源校验组件查询，text 组件不需要数据源
+func validateWidgetQuery(input MonitoringWidgetInput) error {
+	if input.DataSource == "" {
+		if input.Type == "text" {
+			return nil
+		}
+		return fmt.Errorf("组件需要指定数据源")
+	}
+	if !containsString(widgetDataSources, input.DataSource) {
+		return fmt.Errorf("不支持的数据源 %q", input.DataSource)
+	}
+
+	query := WidgetQuery{}
+	if input.Query != nil {
+		query = *input.Query
+	}
+	switch input.DataSource {
+	case WidgetDataSourceMetric, WidgetDataSourceMetricHistory:
+		if len(query.Metrics) == 0 {
+			return fmt.Errorf("指标组件需要指定至少一个指标")
+		}
+		if query.Aggregation != "" && !containsString(widgetAggregation, query.Aggregation) {
+			return fmt.Errorf("不支持的聚合方式 %q", query.Aggregation)
+		}
+		if input.DataSource == WidgetDataSourceMetricHistory {
+			if _, _, err := widgetHistoryWindow(query); err != nil {
+				return err
+			}
+		}
+	case WidgetDataSourceAlerts:
+		if query.Limit < 0 || query.Limit > 100 {
+			return fmt.Errorf("告警数量需要在0到100之间")
+		}
+	}
+	return nil
+}
+
+// widgetHistoryWindow 解析历史查询的范围和步长，未指定步长时按最大点数计算
+func widgetHistoryWindow(query WidgetQuery) (time.Duration, time.Duration, error) {
+	window := time.Hour
+	if query.Range != "" {
+		parsed, err := time.ParseDuration(query.Range)
+		if err != nil || parsed <= 0 {
+			return 0, 0, fmt.Errorf("无效的查询范围 %q", query.Range)
+		}
+		window = parsed
+	}
+	if window > maxWidgetHistoryRange {
+		return 0, 0, fmt.Errorf("查询范围不能超过 %v", maxWidgetHistoryRange)
+	}
+
+	step := time.Duration(0)
+	if query.Step != "" {
+		parsed, err := time.ParseDuration(query.Step)
+		if err != nil || parsed <= 0 {
+			return 0, 0, fmt.Errorf("无效的降采样间隔 %q", query.Step)
+		}
+		step = parsed
+	}
+	if minStep := window / maxWidgetHistoryPoints; step < minStep {
+		step = minStep
+	}
+	if step < time.Second {
+		step = time.Second
+	}
+	return window, step, nil
+}
+
+// downsampleWidgetSeries 按步长分桶聚合采样，采样需按时间升序
+func downsampleWidgetSeries(samples []Models.MetricSample, start time.Time, step time.Duration, aggregation string) []WidgetSeriesPoint {
+	points := make([]WidgetSeriesPoint, 0)
+	var bucket int64 = -1
+	var values []float64
+	flush := func() {
+		if len(values) == 0 {
+			return
+		}
+		points = append(points, WidgetSeriesPoint{
+			Timestamp: start.Add(time.Duration(bucket) * step),
+			Value:     aggregateWidgetValues(values, aggregation),
+		})
+		values = values[:0]
+	}
+
+	for _, sample := range samples {
+		index := int64(sample.Timestamp.Sub(start) / step)
+		if index != bucket {
+			flush()
+			bucket = index
+		}
+		values = append(values, sample.Value)
+	}
+	flush()
+	return points
+}
+
+// aggregateWidgetValues 聚合同一分桶内的值
+func aggregateWidgetValues(values []float64, aggregation string) float64 {
+	switch aggregation {
+	case "max":
+		result := values[0]
+		for _, value := range values[1:] {
+			result = math.Max(result, value)
+		}
+		return result
+	case "min":
+		result := values[0]
+		for _, value := range values[1:] {
+			result = math.Min(result, value)
+		}
+		return result
+	case "last":
+		return values[len(values)-1]
+	}
+
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	if aggregation == "sum" {
+		return sum
+	}
+	return sum / float64(len(values))
+}
+
+// widgetThresholdState 返回指标值达到的最高阈值级别，阈值按数值升序
+func widgetThresholdState(thresholds []WidgetThreshold, value float64) string {
+	state := "normal"
+	for _, threshold := range thresholds {
+		if value >= threshold.Value {
+			state = threshold.Level
+		}
+	}
+	return state
+}
+
+// widgetInput 将组件记录转换为参数，用于导出和查询
+func widgetInput(widget *Models.MonitoringWidget) (MonitoringWidgetInput, error) {
+	input := MonitoringWidgetInput{
+		Name:            widget.Name,
+		Type:            widget.Type,
+		DataSource:      widget.DataSource,
+		Config:          json.RawMessage(widget.Config),
+		RefreshInterval: widget.RefreshInterval,
+		Enabled:         &widget.Enabled,
+	}
+	fields := []struct {
+		raw    string
+		target interface{}
+	}{
+		{widget.Query, &input.Query},
+		{widget.Thresholds, &input.Thresholds},
+		{widget.Position, &input.Position},
+		{widget.Size, &input.Size},
+	}
+	for _, field := range fields {
+		if field.raw == "" {
+			continue
+		}
+		if err := json.Unmarshal([]byte(field.raw), field.target); err != nil {
+			return input, fmt.Errorf("组件 %d 配置损坏: %w", widget.ID, err)
+		}
+	}
+	return input, nil
+}
+
+// ensureDashboardNameAvailable 检查仪表板名称是否被其他仪表板使用
+// 名称有唯一索引，回收站中的仪表板彻底删除前仍然占用名称
+func ensureDashboardNameAvailable(tx *gorm.DB, name string, excludeID uint) error {
+	var count int64
+	if err := tx.Unscoped().Model(&Models.MonitoringDashboard{}).Where("name = ? AND id <> ?", name, excludeID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDashboardNameExists
+	}
+	return nil
+}
+
+// clearOtherDefaultDashboards 设为默认仪表板时取消其他仪表板的默认标记
+func clearOtherDefaultDashboards(tx *gorm.DB, dashboard *Models.MonitoringDashboard) error {
+	if !dashboard.IsDefault {
+		return nil
+	}
+	return tx.Model(&Models.MonitoringDashboard{}).Where("id <> ? AND is_default = ?", dashboard.ID, true).Update("is_default", false).Error
+}
+
+// marshalWidgetJSON 序列化组件的JSON字段
+func marshalWidgetJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,568 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrIncidentNotFound 事件不存在
+	ErrIncidentNotFound = errors.New("事件不存在")
+	// ErrIncidentResolved 事件已解决
+	ErrIncidentResolved = errors.New("事件已解决")
+	// ErrIncidentAcknowledged 事件已确认
+	ErrIncidentAcknowledged = errors.New("事件已确认")
+	// ErrInvalidIncidentComment 评论内容无效
+	ErrInvalidIncidentComment = errors.New("评论内容不能为空且不能超过5000个字符")
+)
+
+// 事件状态
+const (
+	IncidentStatusOpen         = "open"
+	IncidentStatusAcknowledged = "acknowledged"
+	IncidentStatusResolved     = "resolved"
+)
+
+// 事件时间线记录类型
+const (
+	IncidentEventOpened         = "opened"
+	IncidentEventAlertTriggered = "alert_triggered"
+	IncidentEventAlertResolved  = "alert_resolved"
+	IncidentEventAcknowledged   = "acknowledged"
+	IncidentEventComment        = "comment"
+	IncidentEventResolved       = "resolved"
+	IncidentEventReopened       = "reopened"
+)
+
+// maxIncidentCommentLength 评论和解决说明的最大长度
+const maxIncidentCommentLength = 5000
+
+// incidentSeverityRank 告警级别排序，事件严重程度取其中告警的最高级别
+var incidentSeverityRank = map[string]int{
+	string(AlertLevelInfo):     1,
+	string(AlertLevelWarning):  2,
+	string(AlertLevelError):    3,
+	string(AlertLevelCritical): 4,
+}
+
+// incidentEventLabels 复盘报告中时间线记录类型的名称
+var incidentEventLabels = map[string]string{
+	IncidentEventOpened:         "事件打开",
+	IncidentEventAlertTriggered: "告警触发",
+	IncidentEventAlertResolved:  "告警恢复",
+	IncidentEventAcknowledged:   "确认",
+	IncidentEventComment:        "评论",
+	IncidentEventResolved:       "解决",
+	IncidentEventReopened:       "重新打开",
+}
+
+// IncidentDetail 事件及其时间线
+type IncidentDetail struct {
+	Models.MonitoringIncident
+	Timeline []Models.MonitoringIncidentEvent `json:"timeline"`
+}
+
+// IncidentStats 时间范围内打开的事件统计
+// MTTA、MTTR 为打开到首次确认、打开到解决的平均耗时（秒），没有样本时为空
+type IncidentStats struct {
+	From         time.Time      `json:"from"`
+	To           time.Time      `json:"to"`
+	Total        int            `json:"total"`
+	Open         int            `json:"open"`
+	Acknowledged int            `json:"acknowledged"`
+	Resolved     int            `json:"resolved"`
+	Reopened     int            `json:"reopened"`
+	BySeverity   map[string]int `json:"by_severity"`
+	MTTA         *float64       `json:"mtta_seconds"`
+	MTTR         *float64       `json:"mttr_seconds"`
+}
+
+// IncidentReview 事件复盘
+// 耗时单位为秒，未确认或未解决时为空；Duration 为打开到解决（未解决时到生成时间）的耗时
+type IncidentReview struct {
+	IncidentDetail
+	TimeToAcknowledge *float64         `json:"time_to_acknowledge_seconds"`
+	TimeToResolve     *float64         `json:"time_to_resolve_seconds"`
+	Duration          float64          `json:"duration_seconds"`
+	Alerts            []string         `json:"alerts"`
+	AlertComments     []Models.Comment `json:"alert_comments"`
+	GeneratedAt       time.Time        `json:"generated_at"`
+}
+
+// MonitoringIncidentService 事件服务
+// 功能说明：
+// 1. 监听告警引擎的告警状态变化，同一规则在事件解决前触发的告警归入同一事件，事件解决后重新打开窗口内再次触发时重新打开原事件
+// 2. 时间线记录告警触发/恢复、确认、评论、解决和重新打开，事件内告警全部恢复后可自动解决
+// 3. 按打开时间统计MTTA（平均确认耗时）和MTTR（平均解决耗时）
+// 4. 导出事件复盘，包含耗时、涉及的告警、告警评论和完整时间线，支持JSON和Markdown
+type MonitoringIncidentService struct {
+	db       *gorm.DB
+	uow      *Database.UnitOfWork
+	config   *Config.MonitoringConfig
+	comments *CommentService
+	mu       sync.Mutex
+	now      func() time.Time
+}
+
+// NewMonitoringIncidentService 创建事件服务
+func NewMonitoringIncidentService(db *gorm.DB, config *Config.MonitoringConfig) *MonitoringIncidentService {
+	if config == nil {
+		config = &Config.MonitoringConfig{}
+		config.SetDefaults()
+		if globalConfig := Config.GetConfig(); globalConfig != nil {
+			config = &globalConfig.Monitoring
+		}
+	}
+	return &MonitoringIncidentService{db: db, uow: Database.NewUnitOfWork(db), config: config, now: time.Now}
+}
+
+// SetCommentService 设置评论服务，复盘导出时附带涉及告警的评论
+func (s *MonitoringIncidentService) SetCommentService(comments *CommentService) {
+	s.comments = comments
+}
+
+// Attach 监听监控核心的告警状态变化
+func (s *MonitoringIncidentService) Attach(core *MonitoringCore) {
+	core.AddAlertListener(func(alert Alert, transition string) {
+		if err := s.HandleAlertTransition(alert, transition); err != nil {
+			log.Printf("记录事件失败: alert=%s, transition=%s, error=%v", alert.ID, transition, err)
+		}
+	})
+}
+
+// HandleAlertTransition 按告警状态变化更新事件
+func (s *MonitoringIncidentService) HandleAlertTransition(alert Alert, transition string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch transition {
+	case AlertTransitionTriggered:
+		return s.uow.WithTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+			return s.alertTriggered(tx, alert)
+		})
+	case AlertTransitionResolved:
+		return s.uow.WithTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+			return s.alertResolved(tx, alert)
+		})
+	}
+	return nil
+}
+
+// alertTriggered 告警归入同一规则未解决的事件，没有时重新打开窗口内解决的事件或创建新事件
+func (s *MonitoringIncidentService) alertTriggered(tx *gorm.DB, alert Alert) error {
+	now := s.now()
+	level := string(alert.Level)
+
+	var incident Models.MonitoringIncident
+	err := tx.Where("rule_id = ? AND status <> ?", alert.RuleID, IncidentStatusResolved).
+		Order("opened_at DESC").First(&incident).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = s.reopen(tx, &incident, alert, now)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		incident = Models.MonitoringIncident{
+			Title:    alert.Message,
+			RuleID:   alert.RuleID,
+			Severity: level,
+			Status:   IncidentStatusOpen,
+			OpenedAt: now,
+		}
+		if err := tx.Create(&incident).Error; err != nil {
+			return err
+		}
+		err = s.addEvent(tx, incident.ID, IncidentEventOpened, "", nil, alert.Message, nil, now)
+	}
+	if err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{
+		"alert_count":   gorm.Expr("alert_count + 1"),
+		"active_alerts": gorm.Expr("active_alerts + 1"),
+	}
+	if incidentSeverityRank[level] > incidentSeverityRank[incident.Severity] {
+		updates["severity"] = level
+	}
+	if err := tx.Model(&incident).Updates(updates).Error; err != nil {
+		return err
+	}
+	return s.addEvent(tx, incident.ID, IncidentEventAlertTriggered, alert.ID, nil, alert.Message, map[string]interface{}{
+		"level":       alert.Level,
+		"metric":      alert.Metric,
+		"value":       alert.Value,
+		"threshold":   alert.Threshold,
+		"fingerprint": alert.Fingerprint,
+	}, now)
+}
+
+// reopen 重新打开同一规则在窗口内解决的事件，没有时返回 gorm.ErrRecordNotFound
+func (s *MonitoringIncidentService) reopen(tx *gorm.DB, incident *Models.MonitoringIncident, alert Alert, now time.Time) error {
+	window := s.config.Incidents.ReopenWindow
+	if window <= 0 {
+		return gorm.ErrRecordNotFound
+	}
+	err := tx.Where("rule_id = ? AND status = ? AND resolved_at >= ?", alert.RuleID, IncidentStatusResolved, now.Add(-window)).
+		Order("resolved_at DESC").First(incident).Error
+	if err != nil {
+		return err
+	}
+
+	// 重新打开后需要重新确认和解决，首次确认时间保留用于统计MTTA
+	status := IncidentStatusOpen
+	if incident.AcknowledgedAt != nil {
+		status = IncidentStatusAcknowledged
+	}
+	err = tx.Model(incident).Updates(map[string]interface{}{
+		"status":       status,
+		"resolved_at":  nil,
+		"resolved_by":  nil,
+		"reopen_count": gorm.Expr("reopen_count + 1"),
+	}).Error
+	if err != nil {
+		return err
+	}
+	return s.addEvent(tx, incident.ID, IncidentEventReopened, alert.ID, nil, alert.Message, nil, now)
+}
+
+// alertResolved 记录告警恢复，事件内告警全部恢复且启用自动解决时解决事件
+func (s *MonitoringIncidentService) alertResolved(tx *gorm.DB, alert Alert) error {
+	var triggered Models.MonitoringIncidentEvent
+	err := tx.Where("alert_id = ? AND type = ?", alert.ID, IncidentEventAlertTriggered).
+		Order("id DESC").First(&triggered).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 事件功能启用前触发的告警
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var incident Models.MonitoringIncident
+	if err := tx.First(&incident, triggered.IncidentID).Error; err != nil {
+		return err
+	}
+	now := s.now()
+	if err := tx.Model(&incident).Update("active_alerts", gorm.Expr("CASE WHEN active_alerts > 0 THEN active_alerts - 1 ELSE 0 END")).Error; err != nil {
+		return err
+	}
+	if err := s.addEvent(tx, incident.ID, IncidentEventAlertResolved, alert.ID, nil, alert.Message, nil, now); err != nil {
+		return err
+	}
+
+	if !s.config.Incidents.AutoResolve || incident.Status == IncidentStatusResolved {
+		return nil
+	}
+	if err := tx.First(&incident, incident.ID).Error; err != nil {
+		return err
+	}
+	if incident.ActiveAlerts > 0 {
+		return nil
+	}
+	if err := tx.Model(&incident).Updates(map[string]interface{}{
+		"status":      IncidentStatusResolved,
+		"resolved_at": now,
+	}).Error; err != nil {
+		return err
+	}
+	return s.addEvent(tx, incident.ID, IncidentEventResolved, "", nil, "告警全部恢复，事件自动解决", nil, now)
+}
+
+// addEvent 记录时间线
+func (s *MonitoringIncidentService) addEvent(tx *gorm.DB, incidentID uint, eventType, alertID string, userID *uint, message string, data map[string]interface{}, at time.Time) error {
+	event := Models.MonitoringIncidentEvent{
+		IncidentID: incidentID,
+		Type:       eventType,
+		AlertID:    alertID,
+		UserID:     userID,
+		Message:    message,
+		CreatedAt:  at,
+	}
+	if data != nil {
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		event.Data = string(encoded)
+	}
+	return tx.Create(&event).Error
+}
+
+// ListIncidents 分页获取事件
+func (s *MonitoringIncidentService) ListIncidents(q *Utils.ListQuery, req Utils.PageRequest) ([]Models.MonitoringIncident, Utils.PageMeta, error) {
+	query, order, err := q.Apply(s.db.Model(&Models.MonitoringIncident{}), req)
+	if err != nil {
+		return nil, Utils.PageMeta{}, err
+	}
+	var incidents []Models.MonitoringIncident
+	meta, err := Utils.Paginate(query, req, order, &incidents)
+	return incidents, meta, err
+}
+
+// GetIncident 获取事件及其时间线，时间线按时间正序
+func (s *MonitoringIncidentService) GetIncident(id uint) (*IncidentDetail, error) {
+	var incident Models.MonitoringIncident
+	if err := s.db.First(&incident, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrIncidentNotFound
+		}
+		return nil, err
+	}
+	var timeline []Models.MonitoringIncidentEvent
+	if err := s.db.Where("incident_id = ?", id).Order("created_at ASC, id ASC").Find(&timeline).Error; err != nil {
+		return nil, err
+	}
+	return &IncidentDetail{MonitoringIncident: incident, Timeline: timeline}, nil
+}
+
+// Acknowledge 确认事件
+func (s *MonitoringIncidentService) Acknowledge(id, userID uint, message string) (*IncidentDetail, error) {
+	if len([]rune(message)) > maxIncidentCommentLength {
+		return nil, ErrInvalidIncidentComment
+	}
+	err := s.update(id, func(tx *gorm.DB, incident *Models.MonitoringIncident, now time.Time) error {
+		switch incident.Status {
+		case IncidentStatusResolved:
+			return ErrIncidentResolved
+		case IncidentStatusAcknowledged:
+			return ErrIncidentAcknowledged
+		}
+		updates := map[string]interface{}{"status": IncidentStatusAcknowledged}
+		if incident.AcknowledgedAt == nil {
+			updates["acknowledged_at"] = now
+			updates["acknowledged_by"] = userID
+		}
+		if err := tx.Model(incident).Updates(updates).Error; err != nil {
+			return err
+		}
+		return s.addEvent(tx, incident.ID, IncidentEventAcknowledged, "", &userID, strings.TrimSpace(message), nil, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetIncident(id)
+}
+
+// AddComment 添加评论，已解决的事件也可以补充评论
+func (s *MonitoringIncidentService) AddComment(id, userID uint, message string) (*Models.MonitoringIncidentEvent, error) {
+	message = strings.TrimSpace(message)
+	if message == "" || len([]rune(message)) > maxIncidentCommentLength {
+		return nil, ErrInvalidIncidentComment
+	}
+	var event Models.MonitoringIncidentEvent
+	err := s.update(id, func(tx *gorm.DB, incident *Models.MonitoringIncident, now time.Time) error {
+		event = Models.MonitoringIncidentEvent{
+			IncidentID: incident.ID,
+			Type:       IncidentEventComment,
+			UserID:     &userID,
+			Message:    message,
+			CreatedAt:  now,
+		}
+		return tx.Create(&event).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Resolve 解决事件并记录解决说明
+// 已解决（包括自动解决）的事件只更新解决说明
+func (s *MonitoringIncidentService) Resolve(id, userID uint, note string) (*IncidentDetail, error) {
+	note = strings.TrimSpace(note)
+	if len([]rune(note)) > maxIncidentCommentLength {
+		return nil, ErrInvalidIncidentComment
+	}
+	err := s.update(id, func(tx *gorm.DB, incident *Models.MonitoringIncident, now time.Time) error {
+		updates := map[string]interface{}{}
+		if note != "" {
+			updates["resolution_note"] = note
+		}
+		if incident.Status != IncidentStatusResolved {
+			updates["status"] = IncidentStatusResolved
+			updates["resolved_at"] = now
+			updates["resolved_by"] = userID
+		} else if note == "" {
+			return ErrIncidentResolved
+		}
+		if err := tx.Model(incident).Updates(updates).Error; err != nil {
+			return err
+		}
+		return s.addEvent(tx, incident.ID, IncidentEventResolved, "", &userID, note, nil, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetIncident(id)
+}
+
+// update 在事务中加载事件并执行修改
+func (s *MonitoringIncidentService) update(id uint, fn func(tx *gorm.DB, incident *Models.MonitoringIncident, now time.Time) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.uow.WithTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+		var incident Models.MonitoringIncident
+		if err := tx.First(&incident, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrIncidentNotFound
+			}
+			return err
+		}
+		return fn(tx, &incident, s.now())
+	})
+}
+
+// Stats 统计 [from, to) 内打开的事件
+func (s *MonitoringIncidentService) Stats(from, to time.Time) (*IncidentStats, error) {
+	var incidents []Models.MonitoringIncident
+	if err := s.db.Where("opened_at >= ? AND opened_at < ?", from, to).Find(&incidents).Error; err != nil {
+		return nil, err
+	}
+
+	stats := &IncidentStats{From: from, To: to, Total: len(incidents), BySeverity: make(map[string]int)}
+	var ackTotal, resolveTotal time.Duration
+	var ackCount, resolveCount int
+	for _, incident := range incidents {
+		stats.BySeverity[incident.Severity]++
+		switch incident.Status {
+		case IncidentStatusOpen:
+			stats.Open++
+		case IncidentStatusAcknowledged:
+			stats.Acknowledged++
+		case IncidentStatusResolved:
+			stats.Resolved++
+		}
+		if incident.ReopenCount > 0 {
+			stats.Reopened++
+		}
+		if incident.AcknowledgedAt != nil {
+			ackTotal += incident.AcknowledgedAt.Sub(incident.OpenedAt)
+			ackCount++
+		}
+		if incident.ResolvedAt != nil {
+			resolveTotal += incident.ResolvedAt.Sub(incident.OpenedAt)
+			resolveCount++
+		}
+	}
+	if ackCount > 0 {
+		mtta := ackTotal.Seconds() / float64(ackCount)
+		stats.MTTA = &mtta
+	}
+	if resolveCount > 0 {
+		mttr := resolveTotal.Seconds() / float64(resolveCount)
+		stats.MTTR = &mttr
+	}
+	return stats, nil
+}
+
+// Review 生成事件复盘
+func (s *MonitoringIncidentService) Review(id uint) (*IncidentReview, error) {
+	detail, err := s.GetIncident(id)
+	if err != nil {
+		return nil, err
+	}
+
+	review := &IncidentReview{IncidentDetail: *detail, Alerts: make([]string, 0), AlertComments: make([]Models.Comment, 0), GeneratedAt: s.now()}
+	if detail.AcknowledgedAt != nil {
+		seconds := detail.AcknowledgedAt.Sub(detail.OpenedAt).Seconds()
+		review.TimeToAcknowledge = &seconds
+	}
+	end := review.GeneratedAt
+	if detail.ResolvedAt != nil {
+		end = *detail.ResolvedAt
+		seconds := end.Sub(detail.OpenedAt).Seconds()
+		review.TimeToResolve = &seconds
+	}
+	review.Duration = end.Sub(detail.OpenedAt).Seconds()
+
+	seen := make(map[string]bool)
+	for _, event := range detail.Timeline {
+		if event.Type == IncidentEventAlertTriggered && !seen[event.AlertID] {
+			seen[event.AlertID] = true
+			review.Alerts = append(review.Alerts, event.AlertID)
+		}
+	}
+	if s.comments != nil {
+		if review.AlertComments, err = s.comments.Trail(Models.CommentResourceAlert, review.Alerts); err != nil {
+			return nil, err
+		}
+	}
+	return review, nil
+}
+
+// Markdown 以Markdown格式输出复盘，用于事后分析文档
+func (r *IncidentReview) Markdown() string {
+	const layout = "2006-01-02 15:04:05 MST"
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# 事件复盘 #%d: %s\n\n", r.ID, r.Title)
+	fmt.Fprintf(&b, "- 状态: %s\n", r.Status)
+	fmt.Fprintf(&b, "- 严重程度: %s\n", r.Severity)
+	fmt.Fprintf(&b, "- 告警规则: %s\n", r.RuleID)
+	fmt.Fprintf(&b, "- 打开时间: %s\n", r.OpenedAt.Format(layout))
+	if r.AcknowledgedAt != nil {
+		fmt.Fprintf(&b, "- 确认时间: %s（耗时 %s）\n", r.AcknowledgedAt.Format(layout), formatReviewSeconds(*r.TimeToAcknowledge))
+	}
+	if r.ResolvedAt != nil {
+		fmt.Fprintf(&b, "- 解决时间: %s（耗时 %s）\n", r.ResolvedAt.Format(layout), formatReviewSeconds(*r.TimeToResolve))
+	} else {
+		fmt.Fprintf(&b, "- 持续时间: %s（未解决）\n", formatReviewSeconds(r.Duration))
+	}
+	fmt.Fprintf(&b, "- 告警数: %d，重新打开 %d 次\n", r.AlertCount, r.ReopenCount)
+
+	b.WriteString("\n## 解决说明\n\n")
+	if r.ResolutionNote != "" {
+		b.WriteString(r.ResolutionNote)
+	} else {
+		b.WriteString("（无）")
+	}
+	b.WriteString("\n\n## 时间线\n\n| 时间 | 类型 | 告警 | 用户 | 说明 |\n| --- | --- | --- | --- | --- |\n")
+	for _, event := range r.Timeline {
+		label := incidentEventLabels[event.Type]
+		if label == "" {
+			label = event.Type
+		}
+		user := "系统"
+		if event.UserID != nil {
+			user = fmt.Sprintf("用户 #%d", *event.UserID)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", event.CreatedAt.Format(layout), label,
+			markdownCell(event.AlertID), user, markdownCell(event.Message))
+	}
+	if len(r.AlertComments) > 0 {
+		b.WriteString("\n## 告警评论\n\n| 时间 | 告警 | 用户 | 内容 |\n| --- | --- | --- | --- |\n")
+		for _, comment := range r.AlertComments {
+			content := comment.Content
+			if comment.EditedAt != nil {
+				content += "（已编辑）"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", comment.CreatedAt.Format(layout),
+				markdownCell(comment.ResourceID), markdownCell(comment.Username), markdownCell(content))
+		}
+	}
+	fmt.Fprintf(&b, "\n生成时间: %s\n", r.GeneratedAt.Format(layout))
+	return b.String()
+}
+
+// formatReviewSeconds 把秒数格式化为时长
+func formatReviewSeconds(seconds float64) string {
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+// markdownCell 转义表格单元格中的竖线和换行
+func markdownCell(value string) string {
+	value = strings.ReplaceAll(value, "|", "\\|")
+	return strings.ReplaceAll(strings.ReplaceAll(value, "\r\n", "<br>"), "\n", "<br>")
+}
@@ -3,7 +3,6 @@ package Services
 import (
 	"cloud-platform-api/app/Storage"
 	"fmt"
-	"runtime"
 	"sync"
 	"time"
 )
@@ -17,14 +16,16 @@ type CircuitBreakerInterface interface {
 }
 
 // MonitoringIntegrationService 监控集成服务
+// 兼容外观：性能指标阈值作为规则交给统一监控核心的告警引擎评估，
+// 熔断器告警和通知通道都经过监控核心的通知管道
 type MonitoringIntegrationService struct {
 	BaseService
-	storageManager       *Storage.StorageManager
-	circuitBreakers      map[string]CircuitBreakerInterface
-	performanceMetrics   map[string]interface{}
-	mutex                sync.RWMutex
-	alertThresholds      map[string]float64
-	notificationChannels []NotificationChannel
+	storageManager     *Storage.StorageManager
+	circuitBreakers    map[string]CircuitBreakerInterface
+	performanceMetrics map[string]interface{}
+	mutex              sync.RWMutex
+	alertThresholds    map[string]float64
+	core               *MonitoringCore
 }
 
 // NotificationChannel 通知通道接口
@@ -46,6 +47,10 @@ type MonitoringAlert struct {
 	Metadata   map[string]interface{} `json:"metadata"`
 	Resolved   bool                   `json:"resolved"`
 	ResolvedAt *time.Time             `json:"resolved_at,omitempty"`
+
+	// 由请求触发的告警（如安全事件响应）携带请求ID和关联ID，Webhook通道作为请求头发送
+	RequestID     string `json:"request_id,omitempty"`
+	CorrelationID string `json:"correlation_id,omitempty"`
 }
 
 // CircuitBreakerAlert 熔断器告警
@@ -67,8 +72,9 @@ type PerformanceAlert struct {
 }
 
 // NewMonitoringIntegrationService 创建监控集成服务
+// 使用全局监控核心，默认阈值注册为核心的固定阈值规则
 func NewMonitoringIntegrationService(storageManager *Storage.StorageManager) *MonitoringIntegrationService {
-	return &MonitoringIntegrationService{
+	mis := &MonitoringIntegrationService{
 		storageManager:     storageManager,
 		circuitBreakers:    make(map[string]CircuitBreakerInterface),
 		performanceMetrics: make(map[string]interface{}),
@@ -79,7 +85,32 @@ func NewMonitoringIntegrationService(storageManager *Storage.StorageManager) *Mo
 			"memory_usage":    80.0, // 80%
 			"circuit_breaker": 0.5,  // 50%失败率
 		},
-		notificationChannels: make([]NotificationChannel, 0),
+	}
+	mis.SetMonitoringCore(DefaultMonitoringCore())
+	return mis
+}
+
+// SetMonitoringCore 设置监控核心，并将当前阈值注册为核心的告警规则
+func (mis *MonitoringIntegrationService) SetMonitoringCore(core *MonitoringCore) {
+	mis.mutex.Lock()
+	defer mis.mutex.Unlock()
+
+	mis.core = core
+	for metricName, threshold := range mis.alertThresholds {
+		mis.registerThreshold(metricName, threshold)
+	}
+}
+
+// registerThreshold 注册阈值规则，熔断器失败率由 MonitorCircuitBreakers 处理
+func (mis *MonitoringIntegrationService) registerThreshold(metricName string, threshold float64) {
+	if metricName == "circuit_breaker" {
+		return
+	}
+	if err := mis.core.SetThreshold(metricName, ">", threshold, AlertLevelWarning); err != nil {
+		mis.storageManager.LogError("注册告警阈值失败", map[string]interface{}{
+			"metric_name": metricName,
+			"error":       err.Error(),
+		})
 	}
 }
 
@@ -108,57 +139,22 @@ func (mis *MonitoringIntegrationService) UnregisterCircuitBreaker(name string) {
 }
 
 // UpdatePerformanceMetrics 更新性能指标
+// 数值型指标上报到监控核心并评估告警规则
 func (mis *MonitoringIntegrationService) UpdatePerformanceMetrics(metrics map[string]interface{}) {
 	mis.mutex.Lock()
-	defer mis.mutex.Unlock()
-
 	for key, value := range metrics {
 		mis.performanceMetrics[key] = value
-	}
-
-	// 检查性能告警
-	mis.checkPerformanceAlerts(metrics)
-}
-
-// checkPerformanceAlerts 检查性能告警
-func (mis *MonitoringIntegrationService) checkPerformanceAlerts(metrics map[string]interface{}) {
-	for metricName, value := range metrics {
-		if threshold, exists := mis.alertThresholds[metricName]; exists {
-			if floatValue, ok := value.(float64); ok {
-				if floatValue > threshold {
-					alert := MonitoringAlert{
-						ID:        fmt.Sprintf("perf_%s_%d", metricName, time.Now().Unix()),
-						Type:      "performance",
-						Severity:  mis.getSeverity(floatValue, threshold),
-						Title:     fmt.Sprintf("性能指标告警: %s", metricName),
-						Message:   fmt.Sprintf("%s 当前值 %.2f 超过阈值 %.2f", metricName, floatValue, threshold),
-						Source:    "performance_monitor",
-						Timestamp: time.Now(),
-						Metadata: map[string]interface{}{
-							"metric_name": metricName,
-							"value":       floatValue,
-							"threshold":   threshold,
-						},
-					}
-
-					mis.sendAlert(alert)
-				}
-			}
+		if floatValue, ok := metricSampleValue(value); ok {
+			mis.core.Observe(key, floatValue)
 		}
 	}
-}
+	core := mis.core
+	mis.mutex.Unlock()
 
-// getSeverity 获取告警严重程度
-func (mis *MonitoringIntegrationService) getSeverity(value, threshold float64) string {
-	ratio := value / threshold
-	if ratio >= 2.0 {
-		return "critical"
-	} else if ratio >= 1.5 {
-		return "high"
-	} else if ratio >= 1.2 {
-		return "medium"
-	} else {
-		return "low"
+	if err := core.CheckAlerts(); err != nil {
+		mis.storageManager.LogError("告警评估失败", map[string]interface{}{
+			"error": err.Error(),
+		})
 	}
 }
 
@@ -200,10 +196,7 @@ func (mis *MonitoringIntegrationService) MonitorCircuitBreakers() {
 
 // AddNotificationChannel 添加通知通道
 func (mis *MonitoringIntegrationService) AddNotificationChannel(channel NotificationChannel) {
-	mis.mutex.Lock()
-	defer mis.mutex.Unlock()
-
-	mis.notificationChannels = append(mis.notificationChannels, channel)
+	mis.core.Pipeline().AddChannel(channel)
 	mis.storageManager.LogInfo("通知通道已添加", map[string]interface{}{
 		"channel": channel.GetName(),
 		"time":    time.Now(),
@@ -212,24 +205,11 @@ func (mis *MonitoringIntegrationService) AddNotificationChannel(channel Notifica
 
 // RemoveNotificationChannel 移除通知通道
 func (mis *MonitoringIntegrationService) RemoveNotificationChannel(channelName string) {
-	mis.mutex.Lock()
-	defer mis.mutex.Unlock()
-
-	for i, channel := range mis.notificationChannels {
-		if channel.GetName() == channelName {
-			mis.notificationChannels = append(mis.notificationChannels[:i], mis.notificationChannels[i+1:]...)
-			break
-		}
-	}
+	mis.core.Pipeline().RemoveChannel(channelName)
 }
 
 // sendAlert 发送告警
 func (mis *MonitoringIntegrationService) sendAlert(alert MonitoringAlert) {
-	mis.mutex.RLock()
-	channels := make([]NotificationChannel, len(mis.notificationChannels))
-	copy(channels, mis.notificationChannels)
-	mis.mutex.RUnlock()
-
 	// 记录告警到日志
 	mis.storageManager.LogWarning("系统告警", map[string]interface{}{
 		"alert_id":  alert.ID,
@@ -242,20 +222,16 @@ func (mis *MonitoringIntegrationService) sendAlert(alert MonitoringAlert) {
 		"metadata":  alert.Metadata,
 	})
 
-	// 发送到所有启用的通知通道
-	for _, channel := range channels {
-		if channel.IsEnabled() {
-			go func(ch NotificationChannel) {
-				if err := ch.SendAlert(alert); err != nil {
-					mis.storageManager.LogError("发送告警失败", map[string]interface{}{
-						"channel":  ch.GetName(),
-						"alert_id": alert.ID,
-						"error":    err.Error(),
-					})
-				}
-			}(channel)
+	// 通过监控核心的通知管道发送到所有启用的通道
+	go func() {
+		for channel, err := range mis.core.Pipeline().Dispatch(alert) {
+			mis.storageManager.LogError("发送告警失败", map[string]interface{}{
+				"channel":  channel,
+				"alert_id": alert.ID,
+				"error":    err.Error(),
+			})
 		}
-	}
+	}()
 }
 
 // GetMonitoringStatus 获取监控状态
@@ -267,7 +243,7 @@ func (mis *MonitoringIntegrationService) GetMonitoringStatus() map[string]interf
 		"circuit_breakers":      make(map[string]interface{}),
 		"performance_metrics":   mis.performanceMetrics,
 		"alert_thresholds":      mis.alertThresholds,
-		"notification_channels": len(mis.notificationChannels),
+		"notification_channels": len(mis.core.Pipeline().Channels()),
 		"timestamp":             time.Now(),
 	}
 
@@ -285,6 +261,7 @@ func (mis *MonitoringIntegrationService) SetAlertThreshold(metricName string, th
 	defer mis.mutex.Unlock()
 
 	mis.alertThresholds[metricName] = threshold
+	mis.registerThreshold(metricName, threshold)
 	mis.storageManager.LogInfo("告警阈值已更新", map[string]interface{}{
 		"metric_name": metricName,
 		"threshold":   threshold,
@@ -328,40 +305,18 @@ func (mis *MonitoringIntegrationService) monitoringLoop() {
 }
 
 // checkSystemResources 检查系统资源
+// 由监控核心执行采集器（含运行时内存、CPU指标）并评估阈值规则
 func (mis *MonitoringIntegrationService) checkSystemResources() {
-	// 这里可以添加系统资源检查逻辑
-	// 例如：CPU使用率、内存使用率、磁盘空间等
-
-	// 示例：检查内存使用率
-	var m runtime.MemStats
-	runtime.ReadMemStats(&m)
-
-	memoryUsage := float64(m.Alloc) / float64(m.Sys) * 100
-
-	if memoryUsage > mis.alertThresholds["memory_usage"] {
-		alert := MonitoringAlert{
-			ID:        fmt.Sprintf("mem_%d", time.Now().Unix()),
-			Type:      "system_resource",
-			Severity:  mis.getSeverity(memoryUsage, mis.alertThresholds["memory_usage"]),
-			Title:     "内存使用率告警",
-			Message:   fmt.Sprintf("内存使用率 %.2f%% 超过阈值 %.2f%%", memoryUsage, mis.alertThresholds["memory_usage"]),
-			Source:    "system_monitor",
-			Timestamp: time.Now(),
-			Metadata: map[string]interface{}{
-				"memory_usage": memoryUsage,
-				"threshold":    mis.alertThresholds["memory_usage"],
-			},
-		}
-
-		mis.sendAlert(alert)
+	if err := mis.core.Evaluate(); err != nil {
+		mis.storageManager.LogError("告警评估失败", map[string]interface{}{
+			"error": err.Error(),
+		})
 	}
 }
 
 // GetAlertHistory 获取告警历史
 func (mis *MonitoringIntegrationService) GetAlertHistory(limit int) ([]MonitoringAlert, error) {
-	// 这里可以从数据库或日志中获取告警历史
-	// 暂时返回空列表
-	return []MonitoringAlert{}, nil
+	return mis.core.AlertHistory(limit), nil
 }
 
 // ResolveAlert 解决告警
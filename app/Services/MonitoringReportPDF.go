@@ -0,0 +1,279 @@
+package Services
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// A4 页面尺寸（pt）和页边距
+const (
+	pdfPageWidth  = 595.28
+	pdfPageHeight = 841.89
+	pdfMargin     = 50.0
+)
+
+// reportPDF 监控报告使用的PDF生成器
+// 功能说明：
+// 1. 只包含报告需要的文字、表格和折线图，不依赖第三方库
+// 2. 文字使用PDF阅读器内置的 STSong-Light 中文字体，不嵌入字体文件，中英文都可以显示
+// 3. 内容按从上到下的顺序排版，空间不足时自动换页
+type reportPDF struct {
+	title string
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	y     float64 // 当前页剩余内容的上边界
+}
+
+// newReportPDF 创建PDF生成器并添加第一页
+func newReportPDF(title string) *reportPDF {
+	pdf := &reportPDF{title: title}
+	pdf.newPage()
+	return pdf
+}
+
+// newPage 添加新页面
+func (p *reportPDF) newPage() {
+	p.page = &bytes.Buffer{}
+	p.pages = append(p.pages, p.page)
+	p.y = pdfPageHeight - pdfMargin
+}
+
+// ensureSpace 当前页剩余高度不足时换页
+func (p *reportPDF) ensureSpace(height float64) {
+	if p.y-height < pdfMargin {
+		p.newPage()
+	}
+}
+
+// contentWidth 页面内容区域宽度
+func (p *reportPDF) contentWidth() float64 {
+	return pdfPageWidth - 2*pdfMargin
+}
+
+// text 在指定位置输出一行文字
+func (p *reportPDF) text(x, y, size float64, s string) {
+	fmt.Fprintf(p.page, "BT /F1 %.1f Tf %.2f %.2f Td <%s> Tj ET\n", size, x, y, pdfTextHex(s))
+}
+
+// setColor 设置描边和填充颜色，取值0-1
+func (p *reportPDF) setColor(r, g, b float64) {
+	fmt.Fprintf(p.page, "%.3f %.3f %.3f RG %.3f %.3f %.3f rg\n", r, g, b, r, g, b)
+}
+
+// Heading 输出标题
+func (p *reportPDF) Heading(s string, size float64) {
+	p.ensureSpace(size * 2)
+	p.y -= size * 1.5
+	p.setColor(0.1, 0.1, 0.1)
+	p.text(pdfMargin, p.y, size, s)
+	p.y -= size * 0.5
+}
+
+// Paragraph 输出段落，超过内容宽度时换行
+func (p *reportPDF) Paragraph(s string, size float64) {
+	p.setColor(0.25, 0.25, 0.25)
+	for _, line := range pdfWrapText(s, size, p.contentWidth()) {
+		p.ensureSpace(size * 1.5)
+		p.y -= size * 1.5
+		p.text(pdfMargin, p.y, size, line)
+	}
+	p.y -= size * 0.5
+}
+
+// Table 输出表格，widths 为各列宽度占内容宽度的比例，单元格超出列宽时截断
+func (p *reportPDF) Table(headers []string, rows [][]string, widths []float64) {
+	const size, rowHeight = 9.0, 18.0
+	width := p.contentWidth()
+	drawRow := func(cells []string, header bool) {
+		p.ensureSpace(rowHeight)
+		top := p.y
+		p.y -= rowHeight
+		if header {
+			p.setColor(0.92, 0.94, 0.97)
+			fmt.Fprintf(p.page, "%.2f %.2f %.2f %.2f re f\n", pdfMargin, p.y, width, rowHeight)
+		}
+		p.setColor(0.8, 0.8, 0.8)
+		fmt.Fprintf(p.page, "0.5 w %.2f %.2f m %.2f %.2f l S\n", pdfMargin, p.y, pdfMargin+width, p.y)
+		p.setColor(0.1, 0.1, 0.1)
+		x := pdfMargin
+		for i, cell := range cells {
+			if i >= len(widths) {
+				break
+			}
+			columnWidth := widths[i] * width
+			p.text(x+4, top-rowHeight+5.5, size, pdfTruncateText(cell, size, columnWidth-8))
+			x += columnWidth
+		}
+	}
+
+	drawRow(headers, true)
+	for _, row := range rows {
+		drawRow(row, false)
+	}
+	p.y -= 10
+}
+
+// LineChart 输出折线图，标出纵轴最小最大值和横轴起止时间
+func (p *reportPDF) LineChart(title string, points []WidgetSeriesPoint, start, end time.Time) {
+	const height, size = 150.0, 8.0
+	p.ensureSpace(height + 40)
+	p.setColor(0.1, 0.1, 0.1)
+	p.y -= 14
+	p.text(pdfMargin, p.y, 10, title)
+	p.y -= 8
+
+	left, width := pdfMargin+40, p.contentWidth()-40
+	bottom := p.y - height
+	p.setColor(0.75, 0.75, 0.75)
+	fmt.Fprintf(p.page, "0.5 w %.2f %.2f %.2f %.2f re S\n", left, bottom, width, height)
+
+	if len(points) == 0 {
+		p.setColor(0.5, 0.5, 0.5)
+		p.text(left+width/2-20, bottom+height/2, size, "无数据")
+	} else {
+		low, high := points[0].Value, points[0].Value
+		for _, point := range points {
+			low = min(low, point.Value)
+			high = max(high, point.Value)
+		}
+		if high == low {
+			high, low = high+1, low-1
+		}
+		span := end.Sub(start).Seconds()
+		if span <= 0 {
+			span = 1
+		}
+
+		p.setColor(0.2, 0.45, 0.8)
+		fmt.Fprint(p.page, "1.2 w ")
+		for i, point := range points {
+			x := left + width*point.Timestamp.Sub(start).Seconds()/span
+			y := bottom + height*(point.Value-low)/(high-low)
+			op := "l"
+			if i == 0 {
+				op = "m"
+			}
+			fmt.Fprintf(p.page, "%.2f %.2f %s ", x, y, op)
+		}
+		fmt.Fprint(p.page, "S\n")
+
+		p.setColor(0.4, 0.4, 0.4)
+		p.text(pdfMargin, bottom+height-size, size, formatReportValue(high))
+		p.text(pdfMargin, bottom, size, formatReportValue(low))
+	}
+
+	p.setColor(0.4, 0.4, 0.4)
+	p.text(left, bottom-12, size, start.Format("01-02 15:04"))
+	endLabel := end.Format("01-02 15:04")
+	p.text(left+width-pdfTextWidth(endLabel, size), bottom-12, size, endLabel)
+	p.y = bottom - 24
+}
+
+// Bytes 生成PDF文件内容
+func (p *reportPDF) Bytes() ([]byte, error) {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	// 对象编号：1目录、2页面树、3-5字体、6文档信息，之后每页依次为页面和内容流
+	kids := make([]string, len(p.pages))
+	for i := range p.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 7+i*2)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+	object("<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H /DescendantFonts [4 0 R] >>")
+	object("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light " +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >> " +
+		"/FontDescriptor 5 0 R /DW 1000 /W [1 95 500 814 939 500] >>")
+	object("<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880] " +
+		"/ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>")
+	object(fmt.Sprintf("<< /Title <FEFF%s> /Producer (cloud-platform-api) /CreationDate (D:%s) >>",
+		pdfTextHex(p.title), time.Now().UTC().Format("20060102150405Z")))
+
+	for i, page := range p.pages {
+		var compressed bytes.Buffer
+		writer := zlib.NewWriter(&compressed)
+		if _, err := writer.Write(page.Bytes()); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] "+
+			"/Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", pdfPageWidth, pdfPageHeight, 8+i*2))
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.Bytes()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 6 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes(), nil
+}
+
+// pdfTextHex 将文字编码为UCS-2大端十六进制串，基本平面以外的字符替换为问号
+func pdfTextHex(s string) string {
+	var builder strings.Builder
+	for _, r := range s {
+		if r > 0xFFFF || utf16.IsSurrogate(r) {
+			r = '?'
+		}
+		fmt.Fprintf(&builder, "%04X", r)
+	}
+	return builder.String()
+}
+
+// pdfTextWidth 估算文字宽度，ASCII字符按半角计算
+func pdfTextWidth(s string, size float64) float64 {
+	width := 0.0
+	for _, r := range s {
+		if r < 0x80 {
+			width += size * 0.5
+		} else {
+			width += size
+		}
+	}
+	return width
+}
+
+// pdfWrapText 按宽度拆分文字为多行
+func pdfWrapText(s string, size, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(s, "\n") {
+		line, lineWidth := []rune{}, 0.0
+		for _, r := range paragraph {
+			runeWidth := pdfTextWidth(string(r), size)
+			if lineWidth+runeWidth > width && len(line) > 0 {
+				lines = append(lines, string(line))
+				line, lineWidth = nil, 0
+			}
+			line = append(line, r)
+			lineWidth += runeWidth
+		}
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+// pdfTruncateText 截断超出宽度的文字
+func pdfTruncateText(s string, size, width float64) string {
+	if pdfTextWidth(s, size) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && pdfTextWidth(string(runes)+"...", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
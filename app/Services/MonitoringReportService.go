@@ -0,0 +1,1141 @@
+package Services
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrReportNotFound 报告不存在
+	ErrReportNotFound = errors.New("报告不存在")
+	// ErrReportFileNotFound 报告文件不存在
+	ErrReportFileNotFound = errors.New("报告文件不存在")
+	// ErrInvalidReport 报告参数无效
+	ErrInvalidReport = errors.New("报告参数无效")
+)
+
+// 报告类型
+const (
+	ReportTypeDaily   = "daily"
+	ReportTypeWeekly  = "weekly"
+	ReportTypeMonthly = "monthly"
+	ReportTypeCustom  = "custom"
+)
+
+// 报告文件格式
+const (
+	ReportFormatPDF  = "pdf"
+	ReportFormatHTML = "html"
+	ReportFormatJSON = "json"
+)
+
+// 报告文件状态
+const (
+	ReportFileGenerated      = "generated"
+	ReportFileDelivered      = "delivered"
+	ReportFileDeliveryFailed = "delivery_failed"
+)
+
+// 报告生成的触发方式
+const (
+	ReportTriggerSchedule = "schedule"
+	ReportTriggerManual   = "manual"
+)
+
+// reportWebhookDependency 报告Webhook在出站HTTP客户端中的依赖名称
+const reportWebhookDependency = "report_webhook"
+
+// defaultReportSchedules 各报告类型未指定调度时使用的cron表达式，均在早上8点生成
+var defaultReportSchedules = map[string]string{
+	ReportTypeDaily:   "0 8 * * *",
+	ReportTypeWeekly:  "0 8 * * 1",
+	ReportTypeMonthly: "0 8 1 * *",
+}
+
+var reportFormatContentTypes = map[string]string{
+	ReportFormatPDF:  "application/pdf",
+	ReportFormatHTML: "text/html; charset=utf-8",
+	ReportFormatJSON: "application/json",
+}
+
+// MonitoringReportTemplate 报告内容配置，保存在 MonitoringReport.Template
+type MonitoringReportTemplate struct {
+	Title         string   `json:"title,omitempty"`       // 报告标题，为空时使用报告名称
+	Metrics       []string `json:"metrics"`               // 统计和绘图的指标
+	Aggregation   string   `json:"aggregation,omitempty"` // 图表降采样聚合方式：avg（默认）、max、min、sum、last
+	IncludeAlerts bool     `json:"include_alerts"`        // 是否包含统计周期内的告警
+	IncludeSLOs   bool     `json:"include_slos"`          // 是否包含截止到周期结束时的SLO达成情况
+}
+
+// MonitoringReportRecipients 报告接收者，保存在 MonitoringReport.Recipients
+type MonitoringReportRecipients struct {
+	Emails   []string `json:"emails,omitempty"`
+	Webhooks []string `json:"webhooks,omitempty"`
+}
+
+// MonitoringReportInput 创建和更新报告的参数
+type MonitoringReportInput struct {
+	Name        string                     `json:"name" binding:"required,max=100"`
+	Description string                     `json:"description" binding:"max=500"`
+	Type        string                     `json:"type" binding:"required"` // daily, weekly, monthly, custom
+	Format      string                     `json:"format"`                  // pdf（默认）、html、json
+	Schedule    string                     `json:"schedule"`                // cron表达式，为空时按类型使用默认调度，custom 类型为空时只能手动生成
+	Range       string                     `json:"range"`                   // custom 类型的统计范围，如 6h，默认24h
+	Template    MonitoringReportTemplate   `json:"template"`
+	Recipients  MonitoringReportRecipients `json:"recipients"`
+	Enabled     *bool                      `json:"enabled"`
+}
+
+// ReportMetricSummary 报告中单个指标的统计
+type ReportMetricSummary struct {
+	Metric string              `json:"metric"`
+	Count  int                 `json:"count"`
+	Min    float64             `json:"min"`
+	Max    float64             `json:"max"`
+	Avg    float64             `json:"avg"`
+	Last   float64             `json:"last"`
+	Points []WidgetSeriesPoint `json:"points,omitempty"`
+}
+
+// MonitoringReportData 报告内容
+type MonitoringReportData struct {
+	ReportID    uint                  `json:"report_id"`
+	Title       string                `json:"title"`
+	Type        string                `json:"type"`
+	PeriodStart time.Time             `json:"period_start"`
+	PeriodEnd   time.Time             `json:"period_end"`
+	GeneratedAt time.Time             `json:"generated_at"`
+	Metrics     []ReportMetricSummary `json:"metrics"`
+	AlertCounts map[string]int        `json:"alert_counts,omitempty"` // 按级别统计的告警数量
+	Alerts      []*Alert              `json:"alerts,omitempty"`       // 周期内最近的告警，最多 maxReportAlerts 条
+	SLOs        []SLOStatus           `json:"slos,omitempty"`         // 截止到周期结束时的SLO达成情况
+}
+
+// maxReportAlerts 报告中列出的告警数量上限
+const maxReportAlerts = 50
+
+// MonitoringReportService 定时监控报告服务
+// 功能说明：
+// 1. 管理报告定义，日报、周报、月报按关联的 MonitoringSchedule 调度生成
+// 2. 统计周期内各指标的最小、最大、平均和最新值，从指标历史绘制趋势图，并汇总告警和SLO达成情况
+// 3. 在服务端渲染为PDF、HTML或JSON文件，保存在 MONITORING_REPORTS_STORAGE_PATH 下
+// 4. 生成后通过邮件（附件）和Webhook投递，投递结果记录在报告文件上
+// 5. 多实例部署时通过条件更新调度抢占，同一次调度只生成一次
+type MonitoringReportService struct {
+	db      *gorm.DB
+	uow     *Database.UnitOfWork
+	config  *Config.MonitoringConfig
+	core    *MonitoringCore
+	history *MetricHistoryService
+	mail    *MailService
+	client  *OutboundHTTPClient
+	slo     *MonitoringSLOService
+}
+
+// NewMonitoringReportService 创建定时监控报告服务
+func NewMonitoringReportService(db *gorm.DB, config *Config.MonitoringConfig) *MonitoringReportService {
+	if config == nil {
+		config = &Config.MonitoringConfig{}
+		config.SetDefaults()
+		if globalConfig := Config.GetConfig(); globalConfig != nil {
+			config = &globalConfig.Monitoring
+		}
+	}
+
+	return &MonitoringReportService{
+		db:     db,
+		uow:    Database.NewUnitOfWork(db),
+		config: config,
+		core:   DefaultMonitoringCore(),
+		mail:   DefaultMailService(),
+		client: GetOutboundHTTPClient(),
+	}
+}
+
+// SetMonitoringCore 设置报告汇总告警使用的监控核心
+func (s *MonitoringReportService) SetMonitoringCore(core *MonitoringCore) {
+	s.core = core
+}
+
+// SetMetricHistory 设置指标历史，未设置时报告中的指标没有数据
+func (s *MonitoringReportService) SetMetricHistory(history *MetricHistoryService) {
+	s.history = history
+}
+
+// SetSLOService 设置SLO服务，未设置时报告不包含SLO
+func (s *MonitoringReportService) SetSLOService(sloService *MonitoringSLOService) {
+	s.slo = sloService
+}
+
+// SetMailService 设置发送报告邮件的邮件服务
+func (s *MonitoringReportService) SetMailService(mailService *MailService) {
+	s.mail = mailService
+}
+
+// SetHTTPClient 设置投递Webhook使用的出站HTTP客户端
+func (s *MonitoringReportService) SetHTTPClient(client *OutboundHTTPClient) {
+	s.client = client
+}
+
+// ListReports 获取全部报告定义
+func (s *MonitoringReportService) ListReports() ([]Models.MonitoringReport, error) {
+	var reports []Models.MonitoringReport
+	if err := s.db.Preload("Schedule").Order("id ASC").Find(&reports).Error; err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// GetReport 获取报告定义
+func (s *MonitoringReportService) GetReport(id uint) (*Models.MonitoringReport, error) {
+	var report Models.MonitoringReport
+	err := s.db.Preload("Schedule").First(&report, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// CreateReport 创建报告，有调度时同时创建 MonitoringSchedule
+func (s *MonitoringReportService) CreateReport(input MonitoringReportInput, userID uint) (*Models.MonitoringReport, error) {
+	report := &Models.MonitoringReport{CreatedBy: userID}
+	expression, err := applyReportInput(report, input)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled := report.Enabled
+	err = s.uow.WithTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+		if err := tx.Create(report).Error; err != nil {
+			return err
+		}
+		// enabled 字段有数据库默认值，创建时零值会被忽略并回填为默认值
+		if !enabled {
+			report.Enabled = false
+			if err := tx.Model(report).Update("enabled", false).Error; err != nil {
+				return err
+			}
+		}
+		return s.syncSchedule(tx, report, expression, time.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetReport(report.ID)
+}
+
+// UpdateReport 更新报告，调度表达式变化时重新计算下次运行时间
+func (s *MonitoringReportService) UpdateReport(id uint, input MonitoringReportInput) (*Models.MonitoringReport, error) {
+	report, err := s.GetReport(id)
+	if err != nil {
+		return nil, err
+	}
+	expression, err := applyReportInput(report, input)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.uow.WithTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+		if err := tx.Omit("Schedule").Save(report).Error; err != nil {
+			return err
+		}
+		return s.syncSchedule(tx, report, expression, time.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetReport(report.ID)
+}
+
+// DeleteReport 删除报告及其调度和历史报告文件
+func (s *MonitoringReportService) DeleteReport(id uint) error {
+	report, err := s.GetReport(id)
+	if err != nil {
+		return err
+	}
+
+	var files []Models.MonitoringReportFile
+	if err := s.db.Where("report_id = ?", report.ID).Find(&files).Error; err != nil {
+		return err
+	}
+	err = s.uow.WithTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+		if err := tx.Where("report_id = ?", report.ID).Delete(&Models.MonitoringReportFile{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&Models.MonitoringReport{}, report.ID).Error; err != nil {
+			return err
+		}
+		if report.ScheduleID != nil {
+			return tx.Delete(&Models.MonitoringSchedule{}, *report.ScheduleID).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		removeReportFile(file.FilePath)
+	}
+	return nil
+}
+
+// ListFiles 分页获取报告的历史文件，最新的在前
+func (s *MonitoringReportService) ListFiles(reportID uint, page, limit int) ([]Models.MonitoringReportFile, int64, error) {
+	if _, err := s.GetReport(reportID); err != nil {
+		return nil, 0, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	query := s.db.Model(&Models.MonitoringReportFile{}).Where("report_id = ?", reportID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var files []Models.MonitoringReportFile
+	if err := query.Order("created_at DESC, id DESC").Offset((page - 1) * limit).Limit(limit).Find(&files).Error; err != nil {
+		return nil, 0, err
+	}
+	return files, total, nil
+}
+
+// GetFile 获取报告文件记录和文件类型
+func (s *MonitoringReportService) GetFile(reportID, fileID uint) (*Models.MonitoringReportFile, string, error) {
+	var file Models.MonitoringReportFile
+	err := s.db.Where("report_id = ?", reportID).First(&file, fileID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "", ErrReportFileNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := os.Stat(file.FilePath); err != nil {
+		return nil, "", ErrReportFileNotFound
+	}
+	return &file, reportFormatContentTypes[file.Format], nil
+}
+
+// Generate 生成报告并投递，at 为统计周期的参考时间：日报、周报、月报统计其之前完整的自然日、七天和自然月
+func (s *MonitoringReportService) Generate(id uint, trigger string, at time.Time) (*Models.MonitoringReportFile, error) {
+	report, err := s.GetReport(id)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.BuildReportData(report, at)
+	if err != nil {
+		return nil, err
+	}
+	content, err := RenderMonitoringReport(data, report.Format)
+	if err != nil {
+		return nil, fmt.Errorf("渲染报告失败: %w", err)
+	}
+
+	dir := filepath.Join(s.config.Reports.StoragePath, fmt.Sprintf("report-%d", report.ID))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建报告目录失败: %w", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%d.%s", data.PeriodStart.Format("20060102-1504"), data.GeneratedAt.UnixNano(), report.Format))
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return nil, fmt.Errorf("保存报告文件失败: %w", err)
+	}
+
+	file := &Models.MonitoringReportFile{
+		ReportID:    report.ID,
+		Format:      report.Format,
+		FileName:    reportFileName(report.Name, data.PeriodStart, report.Format),
+		FilePath:    path,
+		Size:        int64(len(content)),
+		PeriodStart: data.PeriodStart,
+		PeriodEnd:   data.PeriodEnd,
+		Trigger:     trigger,
+		Status:      ReportFileGenerated,
+	}
+	if err := s.db.Create(file).Error; err != nil {
+		removeReportFile(path)
+		return nil, err
+	}
+	s.db.Model(&Models.MonitoringReport{}).Where("id = ?", report.ID).Update("last_generated", data.GeneratedAt)
+
+	s.deliver(report, file, data, content)
+	return file, nil
+}
+
+// BuildReportData 统计报告周期内的指标和告警
+func (s *MonitoringReportService) BuildReportData(report *Models.MonitoringReport, at time.Time) (*MonitoringReportData, error) {
+	var tmpl MonitoringReportTemplate
+	if err := json.Unmarshal([]byte(report.Template), &tmpl); err != nil {
+		return nil, fmt.Errorf("%w：报告模板不是有效的JSON", ErrInvalidReport)
+	}
+	start, end, err := reportPeriod(report, at)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &MonitoringReportData{
+		ReportID:    report.ID,
+		Title:       tmpl.Title,
+		Type:        report.Type,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		GeneratedAt: time.Now(),
+		Metrics:     make([]ReportMetricSummary, 0, len(tmpl.Metrics)),
+	}
+	if data.Title == "" {
+		data.Title = report.Name
+	}
+
+	maxPoints := s.config.Reports.MaxPoints
+	if maxPoints <= 0 {
+		maxPoints = 200
+	}
+	step := end.Sub(start) / time.Duration(maxPoints)
+	if step < time.Minute {
+		step = time.Minute
+	}
+	for _, metric := range tmpl.Metrics {
+		summary := ReportMetricSummary{Metric: metric}
+		if s.history != nil {
+			samples, err := s.history.Query(metric, start, end)
+			if err != nil {
+				return nil, err
+			}
+			summarizeReportSamples(&summary, samples)
+			summary.Points = downsampleWidgetSeries(samples, start, step, tmpl.Aggregation)
+		}
+		data.Metrics = append(data.Metrics, summary)
+	}
+
+	if tmpl.IncludeAlerts && s.core != nil {
+		data.AlertCounts = make(map[string]int)
+		for _, alert := range s.core.Alerts("", 0) {
+			if alert.CreatedAt.Before(start) || !alert.CreatedAt.Before(end) {
+				continue
+			}
+			data.AlertCounts[string(alert.Level)]++
+			if len(data.Alerts) < maxReportAlerts {
+				data.Alerts = append(data.Alerts, alert)
+			}
+		}
+	}
+
+	if tmpl.IncludeSLOs && s.slo != nil {
+		statuses, err := s.slo.Statuses(end)
+		if err != nil {
+			return nil, err
+		}
+		data.SLOs = statuses
+	}
+	return data, nil
+}
+
+// RunDueReports 生成调度已到期的报告，返回生成的数量
+func (s *MonitoringReportService) RunDueReports(now time.Time) int {
+	var reports []Models.MonitoringReport
+	if err := s.db.Preload("Schedule").Where("enabled = ? AND schedule_id IS NOT NULL", true).Find(&reports).Error; err != nil {
+		log.Printf("查询定时报告失败: %v", err)
+		return 0
+	}
+
+	generated := 0
+	for _, report := range reports {
+		schedule := report.Schedule
+		if schedule == nil || !schedule.Enabled || schedule.NextRun == nil || schedule.NextRun.After(now) {
+			continue
+		}
+		cron, err := ParseCronSchedule(schedule.Expression)
+		if err != nil {
+			log.Printf("报告调度表达式无效: report=%d, expression=%s, error=%v", report.ID, schedule.Expression, err)
+			continue
+		}
+
+		// 错过的调度只补生成一次，下次运行时间从当前时间起算
+		// 以运行次数作为版本号抢占，多实例部署时只有一个实例生成
+		dueAt := *schedule.NextRun
+		next := cron.Next(now)
+		claim := s.db.Model(&Models.MonitoringSchedule{}).
+			Where("id = ? AND run_count = ?", schedule.ID, schedule.RunCount).
+			Updates(map[string]interface{}{"next_run": next, "last_run": now, "run_count": gorm.Expr("run_count + 1")})
+		if claim.Error != nil || claim.RowsAffected == 0 {
+			continue
+		}
+
+		counter := "success_count"
+		file, err := s.Generate(report.ID, ReportTriggerSchedule, dueAt)
+		if err != nil {
+			log.Printf("生成定时报告失败: report=%d, error=%v", report.ID, err)
+			counter = "failure_count"
+		} else {
+			generated++
+			if file.Status == ReportFileDeliveryFailed {
+				counter = "failure_count"
+			}
+		}
+		s.db.Model(&Models.MonitoringSchedule{}).Where("id = ?", schedule.ID).Update(counter, gorm.Expr(counter+" + 1"))
+	}
+	return generated
+}
+
+// Cleanup 分批删除超过保留时间的报告文件和记录，由清理调度器定期运行
+// 每批先删除文件再删除记录，批次之间暂停，ctx 取消时在当前批次完成后返回
+func (s *MonitoringReportService) Cleanup(ctx context.Context, now time.Time) (int64, error) {
+	options := cleanupBatchOptions(ctx)
+	cutoff := now.Add(-s.config.Reports.Retention)
+
+	var deleted int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+		var files []Models.MonitoringReportFile
+		if err := s.db.WithContext(ctx).Where("created_at < ?", cutoff).Order("id").Limit(options.BatchSize).Find(&files).Error; err != nil {
+			return deleted, err
+		}
+		if len(files) == 0 {
+			return deleted, nil
+		}
+		ids := make([]uint, 0, len(files))
+		for _, file := range files {
+			removeReportFile(file.FilePath)
+			ids = append(ids, file.ID)
+		}
+		n, err := BatchDelete(ctx, s.db.Where("id IN ?", ids), &Models.MonitoringReportFile{})
+		deleted += n
+		if err != nil || len(files) < options.BatchSize {
+			return deleted, err
+		}
+		if err := sleepContext(ctx, options.Pause); err != nil {
+			return deleted, err
+		}
+	}
+}
+
+// StartScheduler 启动报告调度，按 CheckInterval 检查到期的报告，ctx 取消时停止；过期文件由清理调度器清理
+func (s *MonitoringReportService) StartScheduler(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.config.Reports.CheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.RunDueReports(now)
+			}
+		}
+	}()
+}
+
+// syncSchedule 按表达式创建、更新或删除报告关联的调度
+func (s *MonitoringReportService) syncSchedule(tx *gorm.DB, report *Models.MonitoringReport, expression string, now time.Time) error {
+	if expression == "" {
+		if report.ScheduleID == nil {
+			return nil
+		}
+		if err := tx.Delete(&Models.MonitoringSchedule{}, *report.ScheduleID).Error; err != nil {
+			return err
+		}
+		report.ScheduleID = nil
+		return tx.Model(report).Update("schedule_id", nil).Error
+	}
+
+	cron, err := ParseCronSchedule(expression)
+	if err != nil {
+		return fmt.Errorf("%w：%v", ErrInvalidReport, err)
+	}
+	next := cron.Next(now)
+	schedule := &Models.MonitoringSchedule{}
+	if report.ScheduleID != nil {
+		if err := tx.First(schedule, *report.ScheduleID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+	if schedule.ID == 0 {
+		schedule.Name = fmt.Sprintf("report-%d", report.ID)
+		schedule.Type = "cron"
+		schedule.CreatedBy = report.CreatedBy
+	}
+	// 表达式不变时保留原来的下次运行时间，避免修改其他字段推迟已到期的调度
+	if schedule.Expression != expression || schedule.NextRun == nil {
+		schedule.NextRun = &next
+	}
+	schedule.Expression = expression
+	schedule.Description = "监控报告：" + report.Name
+	schedule.Enabled = report.Enabled
+	if err := tx.Save(schedule).Error; err != nil {
+		return err
+	}
+	if !report.Enabled {
+		schedule.Enabled = false
+		if err := tx.Model(schedule).Update("enabled", false).Error; err != nil {
+			return err
+		}
+	}
+	report.ScheduleID = &schedule.ID
+	return tx.Model(report).Update("schedule_id", schedule.ID).Error
+}
+
+// deliver 按接收者配置发送邮件和Webhook，结果写入报告文件
+func (s *MonitoringReportService) deliver(report *Models.MonitoringReport, file *Models.MonitoringReportFile, data *MonitoringReportData, content []byte) {
+	var recipients MonitoringReportRecipients
+	if report.Recipients != "" {
+		if err := json.Unmarshal([]byte(report.Recipients), &recipients); err != nil {
+			log.Printf("报告接收者配置无效: report=%d, error=%v", report.ID, err)
+		}
+	}
+	if len(recipients.Emails) == 0 && len(recipients.Webhooks) == 0 {
+		return
+	}
+
+	var failures []string
+	if len(recipients.Emails) > 0 {
+		if err := s.sendReportMail(recipients.Emails, file, data, content); err != nil {
+			failures = append(failures, "邮件: "+err.Error())
+		}
+	}
+	for _, webhook := range recipients.Webhooks {
+		if err := s.sendReportWebhook(webhook, report, file, data); err != nil {
+			failures = append(failures, "Webhook "+webhook+": "+err.Error())
+		}
+	}
+
+	file.Status = ReportFileDelivered
+	file.DeliveryError = ""
+	if len(failures) > 0 {
+		file.Status = ReportFileDeliveryFailed
+		file.DeliveryError = strings.Join(failures, "; ")
+		if len(file.DeliveryError) > 1000 {
+			file.DeliveryError = file.DeliveryError[:1000]
+		}
+		log.Printf("报告投递失败: report=%d, file=%d, error=%s", report.ID, file.ID, file.DeliveryError)
+	}
+	s.db.Model(file).Updates(map[string]interface{}{"status": file.Status, "delivery_error": file.DeliveryError})
+}
+
+// sendReportMail 发送报告邮件，报告文件作为附件，HTML报告同时作为邮件正文
+func (s *MonitoringReportService) sendReportMail(to []string, file *Models.MonitoringReportFile, data *MonitoringReportData, content []byte) error {
+	if s.mail == nil {
+		return fmt.Errorf("邮件服务未配置")
+	}
+
+	message := &Mail{
+		To:      to,
+		Subject: fmt.Sprintf("[监控报告] %s（%s 至 %s）", data.Title, data.PeriodStart.Format("2006-01-02 15:04"), data.PeriodEnd.Format("2006-01-02 15:04")),
+		Text:    reportSummaryText(data),
+	}
+	if file.Format == ReportFormatHTML {
+		message.HTML = string(content)
+	}
+	message.Attach(file.FileName, reportFormatContentTypes[file.Format], content)
+	_, err := s.mail.Queue(message)
+	return err
+}
+
+// sendReportWebhook 向Webhook发送报告摘要和下载地址
+func (s *MonitoringReportService) sendReportWebhook(target string, report *Models.MonitoringReport, file *Models.MonitoringReportFile, data *MonitoringReportData) error {
+	summaries := make([]ReportMetricSummary, 0, len(data.Metrics))
+	for _, metric := range data.Metrics {
+		metric.Points = nil
+		summaries = append(summaries, metric)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"event":        "monitoring.report.generated",
+		"report_id":    report.ID,
+		"report_name":  report.Name,
+		"file_id":      file.ID,
+		"format":       file.Format,
+		"size":         file.Size,
+		"period_start": data.PeriodStart,
+		"period_end":   data.PeriodEnd,
+		"download":     fmt.Sprintf("/api/v1/monitoring/reports/%d/files/%d/download", report.ID, file.ID),
+		"metrics":      summaries,
+		"alert_counts": data.AlertCounts,
+		"slos":         data.SLOs,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(reportWebhookDependency, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("返回错误状态: %s", resp.Status)
+	}
+	return nil
+}
+
+// applyReportInput 校验参数并写入报告，返回调度表达式
+func applyReportInput(report *Models.MonitoringReport, input MonitoringReportInput) (string, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len(name) > 100 {
+		return "", fmt.Errorf("%w：名称不能为空且不超过100个字符", ErrInvalidReport)
+	}
+	format := input.Format
+	if format == "" {
+		format = ReportFormatPDF
+	}
+	if _, ok := reportFormatContentTypes[format]; !ok {
+		return "", fmt.Errorf("%w：不支持的报告格式 %q", ErrInvalidReport, format)
+	}
+
+	expression := strings.TrimSpace(input.Schedule)
+	parameters := "{}"
+	switch input.Type {
+	case ReportTypeDaily, ReportTypeWeekly, ReportTypeMonthly:
+		if expression == "" {
+			expression = defaultReportSchedules[input.Type]
+		}
+	case ReportTypeCustom:
+		window := input.Range
+		if window == "" {
+			window = "24h"
+		}
+		duration, err := time.ParseDuration(window)
+		if err != nil || duration < time.Minute || duration > 366*24*time.Hour {
+			return "", fmt.Errorf("%w：统计范围需要在1分钟到366天之间", ErrInvalidReport)
+		}
+		encoded, _ := json.Marshal(map[string]string{"range": window})
+		parameters = string(encoded)
+	default:
+		return "", fmt.Errorf("%w：不支持的报告类型 %q", ErrInvalidReport, input.Type)
+	}
+	if expression != "" {
+		if _, err := ParseCronSchedule(expression); err != nil {
+			return "", fmt.Errorf("%w：%v", ErrInvalidReport, err)
+		}
+	}
+
+	tmpl := input.Template
+	if len(tmpl.Metrics) == 0 && !tmpl.IncludeSLOs {
+		return "", fmt.Errorf("%w：报告需要至少一个指标或包含SLO", ErrInvalidReport)
+	}
+	if tmpl.Aggregation != "" && !containsString(widgetAggregation, tmpl.Aggregation) {
+		return "", fmt.Errorf("%w：不支持的聚合方式 %q", ErrInvalidReport, tmpl.Aggregation)
+	}
+	for _, address := range input.Recipients.Emails {
+		if _, err := mail.ParseAddress(address); err != nil {
+			return "", fmt.Errorf("%w：邮件地址 %q 无效", ErrInvalidReport, address)
+		}
+	}
+	for _, webhook := range input.Recipients.Webhooks {
+		parsed, err := url.Parse(webhook)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return "", fmt.Errorf("%w：Webhook地址 %q 无效", ErrInvalidReport, webhook)
+		}
+	}
+	recipients, _ := json.Marshal(input.Recipients)
+	if len(recipients) > 1000 {
+		return "", fmt.Errorf("%w：接收者配置过长", ErrInvalidReport)
+	}
+	encodedTemplate, _ := json.Marshal(tmpl)
+
+	report.Name = name
+	report.Description = input.Description
+	report.Type = input.Type
+	report.Format = format
+	report.Template = string(encodedTemplate)
+	report.Parameters = parameters
+	report.Recipients = string(recipients)
+	report.Enabled = input.Enabled == nil || *input.Enabled
+	return expression, nil
+}
+
+// reportPeriod 按报告类型计算统计周期
+func reportPeriod(report *Models.MonitoringReport, at time.Time) (time.Time, time.Time, error) {
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+	switch report.Type {
+	case ReportTypeDaily:
+		return day.AddDate(0, 0, -1), day, nil
+	case ReportTypeWeekly:
+		return day.AddDate(0, 0, -7), day, nil
+	case ReportTypeMonthly:
+		month := time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, at.Location())
+		return month.AddDate(0, -1, 0), month, nil
+	}
+
+	var parameters struct {
+		Range string `json:"range"`
+	}
+	if report.Parameters != "" {
+		if err := json.Unmarshal([]byte(report.Parameters), &parameters); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w：报告参数不是有效的JSON", ErrInvalidReport)
+		}
+	}
+	window := 24 * time.Hour
+	if parameters.Range != "" {
+		duration, err := time.ParseDuration(parameters.Range)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w：统计范围 %q 无效", ErrInvalidReport, parameters.Range)
+		}
+		window = duration
+	}
+	return at.Add(-window), at, nil
+}
+
+// summarizeReportSamples 计算采样的统计值
+func summarizeReportSamples(summary *ReportMetricSummary, samples []Models.MetricSample) {
+	if len(samples) == 0 {
+		return
+	}
+	sum := 0.0
+	summary.Min, summary.Max = samples[0].Value, samples[0].Value
+	for _, sample := range samples {
+		summary.Min = min(summary.Min, sample.Value)
+		summary.Max = max(summary.Max, sample.Value)
+		sum += sample.Value
+	}
+	summary.Count = len(samples)
+	summary.Avg = sum / float64(len(samples))
+	summary.Last = samples[len(samples)-1].Value
+}
+
+// reportFileName 下载文件名，去掉名称中的路径分隔符
+func reportFileName(name string, start time.Time, format string) string {
+	name = strings.NewReplacer("/", "_", "\\", "_", "\"", "_").Replace(name)
+	return fmt.Sprintf("%s-%s.%s", name, start.Format("20060102"), format)
+}
+
+// removeReportFile 删除报告文件，文件不存在时忽略
+func removeReportFile(path string) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Printf("删除报告文件失败: path=%s, error=%v", path, err)
+	}
+}
+
+// formatReportValue 格式化报告中的数值
+func formatReportValue(value float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", value), "0"), ".")
+}
+
+// reportSummaryText 报告的纯文本摘要，用于邮件正文
+func reportSummaryText(data *MonitoringReportData) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%s\n统计周期：%s 至 %s\n\n", data.Title, data.PeriodStart.Format("2006-01-02 15:04"), data.PeriodEnd.Format("2006-01-02 15:04"))
+	for _, metric := range data.Metrics {
+		if metric.Count == 0 {
+			fmt.Fprintf(&builder, "%s：无数据\n", metric.Metric)
+			continue
+		}
+		fmt.Fprintf(&builder, "%s：平均 %s，最小 %s，最大 %s，最新 %s\n", metric.Metric,
+			formatReportValue(metric.Avg), formatReportValue(metric.Min), formatReportValue(metric.Max), formatReportValue(metric.Last))
+	}
+	if data.AlertCounts != nil {
+		fmt.Fprintf(&builder, "\n告警：%s\n", reportAlertCountText(data.AlertCounts))
+	}
+	if len(data.SLOs) > 0 {
+		builder.WriteString("\nSLO：\n")
+		for _, slo := range data.SLOs {
+			row := reportSLORow(slo)
+			fmt.Fprintf(&builder, "%s：目标 %s，达成 %s，剩余预算 %s，%s\n", row[0], row[1], row[2], row[3], row[6])
+		}
+	}
+	return builder.String()
+}
+
+// reportAlertCountText 按级别排列的告警数量
+func reportAlertCountText(counts map[string]int) string {
+	if len(counts) == 0 {
+		return "无"
+	}
+	levels := make([]string, 0, len(counts))
+	for level := range counts {
+		levels = append(levels, level)
+	}
+	sort.Strings(levels)
+	parts := make([]string, 0, len(levels))
+	for _, level := range levels {
+		parts = append(parts, fmt.Sprintf("%s %d", level, counts[level]))
+	}
+	return strings.Join(parts, "，")
+}
+
+// RenderMonitoringReport 将报告渲染为指定格式
+func RenderMonitoringReport(data *MonitoringReportData, format string) ([]byte, error) {
+	switch format {
+	case ReportFormatPDF:
+		return renderReportPDF(data)
+	case ReportFormatHTML:
+		return renderReportHTML(data)
+	case ReportFormatJSON:
+		return json.MarshalIndent(data, "", "  ")
+	default:
+		return nil, fmt.Errorf("%w：不支持的报告格式 %q", ErrInvalidReport, format)
+	}
+}
+
+// renderReportPDF 渲染PDF报告
+func renderReportPDF(data *MonitoringReportData) ([]byte, error) {
+	pdf := newReportPDF(data.Title)
+	pdf.Heading(data.Title, 18)
+	pdf.Paragraph(fmt.Sprintf("统计周期：%s 至 %s    生成时间：%s",
+		data.PeriodStart.Format("2006-01-02 15:04"), data.PeriodEnd.Format("2006-01-02 15:04"), data.GeneratedAt.Format("2006-01-02 15:04")), 10)
+
+	pdf.Heading("指标概览", 13)
+	rows := make([][]string, 0, len(data.Metrics))
+	for _, metric := range data.Metrics {
+		rows = append(rows, reportMetricRow(metric))
+	}
+	pdf.Table([]string{"指标", "采样数", "平均", "最小", "最大", "最新"}, rows, []float64{0.35, 0.13, 0.13, 0.13, 0.13, 0.13})
+
+	pdf.Heading("趋势", 13)
+	for _, metric := range data.Metrics {
+		pdf.LineChart(metric.Metric, metric.Points, data.PeriodStart, data.PeriodEnd)
+	}
+
+	if data.AlertCounts != nil {
+		pdf.Heading("告警", 13)
+		pdf.Paragraph("按级别统计："+reportAlertCountText(data.AlertCounts), 10)
+		if len(data.Alerts) > 0 {
+			alertRows := make([][]string, 0, len(data.Alerts))
+			for _, alert := range data.Alerts {
+				alertRows = append(alertRows, []string{alert.CreatedAt.Format("01-02 15:04"), string(alert.Level), alert.Status, alert.Message})
+			}
+			pdf.Table([]string{"时间", "级别", "状态", "消息"}, alertRows, []float64{0.15, 0.12, 0.13, 0.6})
+		}
+	}
+
+	if len(data.SLOs) > 0 {
+		pdf.Heading("服务等级目标", 13)
+		sloRows := make([][]string, 0, len(data.SLOs))
+		for _, slo := range data.SLOs {
+			sloRows = append(sloRows, reportSLORow(slo))
+		}
+		pdf.Table(reportSLOHeaders, sloRows, []float64{0.26, 0.1, 0.12, 0.12, 0.12, 0.12, 0.16})
+	}
+	return pdf.Bytes()
+}
+
+// reportMetricRow 指标概览表格的一行
+func reportMetricRow(metric ReportMetricSummary) []string {
+	if metric.Count == 0 {
+		return []string{metric.Metric, "0", "-", "-", "-", "-"}
+	}
+	return []string{
+		metric.Metric,
+		fmt.Sprintf("%d", metric.Count),
+		formatReportValue(metric.Avg),
+		formatReportValue(metric.Min),
+		formatReportValue(metric.Max),
+		formatReportValue(metric.Last),
+	}
+}
+
+// reportSLOHeaders SLO表格的表头
+var reportSLOHeaders = []string{"SLO", "目标", "达成率", "剩余预算", "快速消耗", "慢速消耗", "状态"}
+
+// reportSLORow SLO表格的一行，消耗速率为长窗口和短窗口中的较小值
+func reportSLORow(status SLOStatus) []string {
+	state := "达标"
+	switch {
+	case status.NoData:
+		state = "无数据"
+	case status.FastBurn:
+		state = "预算快速消耗"
+	case status.SlowBurn:
+		state = "预算慢速消耗"
+	case !status.Met:
+		state = "未达标"
+	}
+	return []string{
+		status.Name,
+		formatReportValue(status.Objective) + "%",
+		formatReportValue(status.Compliance) + "%",
+		formatReportValue(status.BudgetRemaining) + "%",
+		formatReportValue(sloWindowBurnRate(&status, sloBurnWindows[0])),
+		formatReportValue(sloWindowBurnRate(&status, sloBurnWindows[1])),
+		state,
+	}
+}
+
+// reportChart HTML报告中的SVG折线图
+type reportChart struct {
+	Metric     string
+	Points     string // polyline 的坐标
+	High       string
+	Low        string
+	HasData    bool
+	StartLabel string
+	EndLabel   string
+}
+
+// SVG图表尺寸
+const (
+	reportChartWidth  = 720.0
+	reportChartHeight = 200.0
+)
+
+// newReportChart 将时间序列转换为SVG坐标
+func newReportChart(metric ReportMetricSummary, start, end time.Time) reportChart {
+	chart := reportChart{
+		Metric:     metric.Metric,
+		HasData:    len(metric.Points) > 0,
+		StartLabel: start.Format("01-02 15:04"),
+		EndLabel:   end.Format("01-02 15:04"),
+	}
+	if !chart.HasData {
+		return chart
+	}
+
+	low, high := metric.Points[0].Value, metric.Points[0].Value
+	for _, point := range metric.Points {
+		low = min(low, point.Value)
+		high = max(high, point.Value)
+	}
+	chart.High, chart.Low = formatReportValue(high), formatReportValue(low)
+	if high == low {
+		high, low = high+1, low-1
+	}
+	span := end.Sub(start).Seconds()
+	if span <= 0 {
+		span = 1
+	}
+
+	coordinates := make([]string, 0, len(metric.Points))
+	for _, point := range metric.Points {
+		x := reportChartWidth * point.Timestamp.Sub(start).Seconds() / span
+		y := reportChartHeight - reportChartHeight*(point.Value-low)/(high-low)
+		coordinates = append(coordinates, fmt.Sprintf("%.1f,%.1f", x, y))
+	}
+	chart.Points = strings.Join(coordinates, " ")
+	return chart
+}
+
+var reportHTMLTemplate = template.Must(template.New("monitoring_report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>{{.Data.Title}}</title>
+    <style>
+        body { font-family: Arial, "Microsoft YaHei", sans-serif; margin: 0; padding: 20px; background-color: #f5f5f5; color: #212529; }
+        .container { max-width: 800px; margin: 0 auto; background-color: white; border-radius: 8px; padding: 24px; }
+        .period { color: #6c757d; font-size: 13px; }
+        table { width: 100%; border-collapse: collapse; margin: 12px 0; font-size: 13px; }
+        th, td { text-align: left; padding: 6px 8px; border-bottom: 1px solid #dee2e6; }
+        th { background-color: #eef1f7; }
+        .chart { margin: 16px 0; }
+        .chart svg { background-color: #fafbfc; border: 1px solid #dee2e6; }
+        .footer { margin-top: 24px; text-align: center; font-size: 12px; color: #6c757d; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <h1>{{.Data.Title}}</h1>
+        <p class="period">统计周期：{{.Data.PeriodStart.Format "2006-01-02 15:04"}} 至 {{.Data.PeriodEnd.Format "2006-01-02 15:04"}}，生成时间：{{.Data.GeneratedAt.Format "2006-01-02 15:04"}}</p>
+
+        <h2>指标概览</h2>
+        <table>
+            <tr><th>指标</th><th>采样数</th><th>平均</th><th>最小</th><th>最大</th><th>最新</th></tr>
+            {{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+            {{end}}
+        </table>
+
+        <h2>趋势</h2>
+        {{range .Charts}}
+        <div class="chart">
+            <h3>{{.Metric}}</h3>
+            <svg width="100%" viewBox="-60 -10 800 240" xmlns="http://www.w3.org/2000/svg">
+                <rect x="0" y="0" width="720" height="200" fill="none" stroke="#ced4da"/>
+                {{if .HasData}}
+                <polyline points="{{.Points}}" fill="none" stroke="#3373cc" stroke-width="1.5"/>
+                <text x="-8" y="10" font-size="11" text-anchor="end" fill="#6c757d">{{.High}}</text>
+                <text x="-8" y="200" font-size="11" text-anchor="end" fill="#6c757d">{{.Low}}</text>
+                {{else}}
+                <text x="360" y="100" font-size="13" text-anchor="middle" fill="#6c757d">无数据</text>
+                {{end}}
+                <text x="0" y="218" font-size="11" fill="#6c757d">{{.StartLabel}}</text>
+                <text x="720" y="218" font-size="11" text-anchor="end" fill="#6c757d">{{.EndLabel}}</text>
+            </svg>
+        </div>
+        {{end}}
+
+        {{if .Data.AlertCounts}}
+        <h2>告警</h2>
+        <p>按级别统计：{{.AlertCountText}}</p>
+        {{if .Data.Alerts}}
+        <table>
+            <tr><th>时间</th><th>级别</th><th>状态</th><th>消息</th></tr>
+            {{range .Data.Alerts}}<tr><td>{{.CreatedAt.Format "01-02 15:04"}}</td><td>{{.Level}}</td><td>{{.Status}}</td><td>{{.Message}}</td></tr>
+            {{end}}
+        </table>
+        {{end}}
+        {{else if .IncludeAlerts}}
+        <h2>告警</h2>
+        <p>统计周期内没有告警</p>
+        {{end}}
+
+        {{if .SLORows}}
+        <h2>服务等级目标</h2>
+        <table>
+            <tr>{{range .SLOHeaders}}<th>{{.}}</th>{{end}}</tr>
+            {{range .SLORows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+            {{end}}
+        </table>
+        {{end}}
+
+        <div class="footer">此报告由云平台监控系统自动生成</div>
+    </div>
+</body>
+</html>
+`))
+
+// renderReportHTML 渲染HTML报告，图表为内嵌SVG，不依赖外部资源
+func renderReportHTML(data *MonitoringReportData) ([]byte, error) {
+	rows := make([][]string, 0, len(data.Metrics))
+	charts := make([]reportChart, 0, len(data.Metrics))
+	for _, metric := range data.Metrics {
+		rows = append(rows, reportMetricRow(metric))
+		charts = append(charts, newReportChart(metric, data.PeriodStart, data.PeriodEnd))
+	}
+	sloRows := make([][]string, 0, len(data.SLOs))
+	for _, slo := range data.SLOs {
+		sloRows = append(sloRows, reportSLORow(slo))
+	}
+
+	var buffer bytes.Buffer
+	err := reportHTMLTemplate.Execute(&buffer, map[string]interface{}{
+		"Data":           data,
+		"Rows":           rows,
+		"Charts":         charts,
+		"IncludeAlerts":  data.AlertCounts != nil,
+		"AlertCountText": reportAlertCountText(data.AlertCounts),
+		"SLOHeaders":     reportSLOHeaders,
+		"SLORows":        sloRows,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,499 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSLONotFound SLO不存在
+	ErrSLONotFound = errors.New("SLO不存在")
+	// ErrSLONameExists SLO名称已存在
+	ErrSLONameExists = errors.New("SLO名称已存在")
+	// ErrInvalidSLO SLO参数无效
+	ErrInvalidSLO = errors.New("SLO参数无效")
+)
+
+// SLO类型
+const (
+	SLOTypeAvailability = "availability" // 可用性：按合格条件统计合格采样比例
+	SLOTypeLatency      = "latency"      // 延迟：采样不超过阈值为合格，同时给出 Objective 分位的观测值
+)
+
+// sloBurnWindow 错误预算消耗告警的多窗口组合
+// 长窗口确认消耗足够多，短窗口确认仍在消耗，两个窗口的速率都超过阈值才告警，恢复也更及时
+type sloBurnWindow struct {
+	name  string
+	long  time.Duration
+	short time.Duration
+	level AlertLevel
+}
+
+var sloBurnWindows = []sloBurnWindow{
+	{name: "fast", long: time.Hour, short: 5 * time.Minute, level: AlertLevelCritical},
+	{name: "slow", long: 6 * time.Hour, short: 30 * time.Minute, level: AlertLevelWarning},
+}
+
+// SLOInput 创建和更新SLO的参数
+type SLOInput struct {
+	Name        string   `json:"name" binding:"required,max=100"`
+	Description string   `json:"description" binding:"max=500"`
+	Type        string   `json:"type" binding:"required"` // availability, latency
+	Metric      string   `json:"metric" binding:"required"`
+	Comparison  string   `json:"comparison"` // 合格条件：>, >=, <, <=，latency 类型固定为 <=，availability 默认 >=
+	Threshold   *float64 `json:"threshold" binding:"required"`
+	Objective   float64  `json:"objective" binding:"required"` // 目标（百分比），0-100之间，不含两端
+	WindowDays  int      `json:"window_days"`                  // 滚动窗口（天），1-90，默认30
+	Enabled     *bool    `json:"enabled"`
+}
+
+// SLOStatus SLO在滚动窗口内的达成情况
+type SLOStatus struct {
+	SLOID           uint               `json:"slo_id"`
+	Name            string             `json:"name"`
+	Type            string             `json:"type"`
+	Metric          string             `json:"metric"`
+	Objective       float64            `json:"objective"`
+	Enabled         bool               `json:"enabled"`
+	WindowStart     time.Time          `json:"window_start"`
+	WindowEnd       time.Time          `json:"window_end"`
+	TotalSamples    int                `json:"total_samples"`
+	GoodSamples     int                `json:"good_samples"`
+	NoData          bool               `json:"no_data"`
+	Compliance      float64            `json:"compliance"`           // 达成率（百分比），无数据时为100
+	Met             bool               `json:"met"`                  // 达成率是否不低于目标
+	BudgetRemaining float64            `json:"budget_remaining"`     // 剩余错误预算（百分比），超支时为负数
+	Percentile      *float64           `json:"percentile,omitempty"` // latency 类型窗口内 Objective 分位的观测值
+	BurnRates       map[string]float64 `json:"burn_rates"`           // 各窗口的预算消耗速率，键为窗口时长如 1h、5m
+	FastBurn        bool               `json:"fast_burn"`            // 快速消耗是否超过阈值
+	SlowBurn        bool               `json:"slow_burn"`            // 慢速消耗是否超过阈值
+}
+
+// MonitoringSLOService 服务等级目标服务
+// 功能说明：
+// 1. 管理SLO定义，SLI按指标历史中的采样计算：满足合格条件的采样为合格采样
+// 2. 计算滚动窗口内的达成率、剩余错误预算和多个窗口的预算消耗速率（坏采样比例 / 允许的坏采样比例）
+// 3. 每个SLO在监控核心注册快速和慢速两条消耗告警规则，评估时把消耗速率作为指标上报，告警仍由唯一的告警引擎评估和通知
+// 4. 报告服务通过 Statuses 把SLO达成情况加入定时报告
+type MonitoringSLOService struct {
+	db      *gorm.DB
+	config  *Config.MonitoringConfig
+	core    *MonitoringCore
+	history *MetricHistoryService
+}
+
+// NewMonitoringSLOService 创建服务等级目标服务
+func NewMonitoringSLOService(db *gorm.DB, config *Config.MonitoringConfig) *MonitoringSLOService {
+	if config == nil {
+		config = &Config.MonitoringConfig{}
+		config.SetDefaults()
+		if globalConfig := Config.GetConfig(); globalConfig != nil {
+			config = &globalConfig.Monitoring
+		}
+	}
+
+	return &MonitoringSLOService{
+		db:     db,
+		config: config,
+		core:   DefaultMonitoringCore(),
+	}
+}
+
+// SetMonitoringCore 设置注册消耗告警规则和上报SLO指标的监控核心
+func (s *MonitoringSLOService) SetMonitoringCore(core *MonitoringCore) {
+	s.core = core
+}
+
+// SetMetricHistory 设置计算SLI使用的指标历史，未设置时所有SLO都没有数据
+func (s *MonitoringSLOService) SetMetricHistory(history *MetricHistoryService) {
+	s.history = history
+}
+
+// ListSLOs 获取全部SLO
+func (s *MonitoringSLOService) ListSLOs() ([]Models.MonitoringSLO, error) {
+	var slos []Models.MonitoringSLO
+	if err := s.db.Order("id ASC").Find(&slos).Error; err != nil {
+		return nil, err
+	}
+	return slos, nil
+}
+
+// GetSLO 获取SLO
+func (s *MonitoringSLOService) GetSLO(id uint) (*Models.MonitoringSLO, error) {
+	var slo Models.MonitoringSLO
+	if err := s.db.First(&slo, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSLONotFound
+		}
+		return nil, err
+	}
+	return &slo, nil
+}
+
+// CreateSLO 创建SLO并注册消耗告警规则
+func (s *MonitoringSLOService) CreateSLO(input SLOInput, userID uint) (*Models.MonitoringSLO, error) {
+	slo := &Models.MonitoringSLO{CreatedBy: userID}
+	if err := applySLOInput(slo, input); err != nil {
+		return nil, err
+	}
+
+	enabled := slo.Enabled
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensureSLONameAvailable(tx, slo.Name, 0); err != nil {
+			return err
+		}
+		if err := tx.Create(slo).Error; err != nil {
+			return err
+		}
+		// enabled 字段有数据库默认值，创建时零值会被忽略并回填为默认值
+		if !enabled {
+			slo.Enabled = false
+			return tx.Model(slo).Update("enabled", false).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.syncRules(slo)
+	return slo, nil
+}
+
+// UpdateSLO 更新SLO并同步消耗告警规则
+func (s *MonitoringSLOService) UpdateSLO(id uint, input SLOInput) (*Models.MonitoringSLO, error) {
+	slo, err := s.GetSLO(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applySLOInput(slo, input); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensureSLONameAvailable(tx, slo.Name, slo.ID); err != nil {
+			return err
+		}
+		return tx.Save(slo).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.syncRules(slo)
+	return slo, nil
+}
+
+// DeleteSLO 删除SLO，同时移除其消耗告警规则
+func (s *MonitoringSLOService) DeleteSLO(id uint) error {
+	slo, err := s.GetSLO(id)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(&Models.MonitoringSLO{}, slo.ID).Error; err != nil {
+		return err
+	}
+	if s.core != nil {
+		for _, window := range sloBurnWindows {
+			s.core.RemoveRule(sloRuleID(slo.ID, window.name))
+		}
+	}
+	return nil
+}
+
+// SyncRules 为全部SLO注册消耗告警规则，服务启动时调用
+func (s *MonitoringSLOService) SyncRules() error {
+	slos, err := s.ListSLOs()
+	if err != nil {
+		return err
+	}
+	for i := range slos {
+		s.syncRules(&slos[i])
+	}
+	return nil
+}
+
+// Status 计算单个SLO截止到 at 的达成情况
+func (s *MonitoringSLOService) Status(id uint, at time.Time) (*SLOStatus, error) {
+	slo, err := s.GetSLO(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.computeStatus(slo, at)
+}
+
+// Statuses 计算全部SLO截止到 at 的达成情况
+func (s *MonitoringSLOService) Statuses(at time.Time) ([]SLOStatus, error) {
+	slos, err := s.ListSLOs()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]SLOStatus, 0, len(slos))
+	for i := range slos {
+		status, err := s.computeStatus(&slos[i], at)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, *status)
+	}
+	return statuses, nil
+}
+
+// Evaluate 计算启用的SLO并把达成率、剩余预算和消耗速率上报到监控核心，返回计算结果
+// 上报的指标名为 slo_<id>_compliance、slo_<id>_error_budget_remaining、slo_<id>_burn_rate_fast 和 slo_<id>_burn_rate_slow
+func (s *MonitoringSLOService) Evaluate(now time.Time) []SLOStatus {
+	var slos []Models.MonitoringSLO
+	if err := s.db.Where("enabled = ?", true).Order("id ASC").Find(&slos).Error; err != nil {
+		log.Printf("查询SLO失败: %v", err)
+		return nil
+	}
+
+	statuses := make([]SLOStatus, 0, len(slos))
+	for i := range slos {
+		status, err := s.computeStatus(&slos[i], now)
+		if err != nil {
+			log.Printf("计算SLO失败: slo=%d, error=%v", slos[i].ID, err)
+			continue
+		}
+		if s.core != nil {
+			s.core.Observe(sloMetricName(status.SLOID, "compliance"), status.Compliance)
+			s.core.Observe(sloMetricName(status.SLOID, "error_budget_remaining"), status.BudgetRemaining)
+			for _, window := range sloBurnWindows {
+				s.core.Observe(sloMetricName(status.SLOID, "burn_rate_"+window.name), sloWindowBurnRate(status, window))
+			}
+		}
+		statuses = append(statuses, *status)
+	}
+	return statuses
+}
+
+// StartEvaluator 注册全部SLO的告警规则并按评估间隔计算SLO，ctx 取消时停止
+func (s *MonitoringSLOService) StartEvaluator(ctx context.Context) {
+	if err := s.SyncRules(); err != nil {
+		log.Printf("注册SLO告警规则失败: %v", err)
+	}
+	go func() {
+		ticker := time.NewTicker(s.config.SLO.EvaluationInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.Evaluate(now)
+			}
+		}
+	}()
+}
+
+// computeStatus 从指标历史计算SLO状态
+func (s *MonitoringSLOService) computeStatus(slo *Models.MonitoringSLO, at time.Time) (*SLOStatus, error) {
+	start := at.Add(-time.Duration(slo.WindowDays) * 24 * time.Hour)
+	status := &SLOStatus{
+		SLOID:       slo.ID,
+		Name:        slo.Name,
+		Type:        slo.Type,
+		Metric:      slo.Metric,
+		Objective:   slo.Objective,
+		Enabled:     slo.Enabled,
+		WindowStart: start,
+		WindowEnd:   at,
+		BurnRates:   make(map[string]float64, len(sloBurnWindows)*2),
+	}
+
+	var samples []Models.MetricSample
+	if s.history != nil {
+		var err error
+		if samples, err = s.history.Query(slo.Metric, start, at); err != nil {
+			return nil, err
+		}
+	}
+
+	// 允许的坏采样比例
+	budget := 1 - slo.Objective/100
+	status.TotalSamples = len(samples)
+	for _, sample := range samples {
+		if compareAlertValue(sample.Value, slo.Comparison, slo.Threshold) {
+			status.GoodSamples++
+		}
+	}
+	status.NoData = status.TotalSamples == 0
+	status.Compliance = 100
+	status.BudgetRemaining = 100
+	if !status.NoData {
+		badRatio := float64(status.TotalSamples-status.GoodSamples) / float64(status.TotalSamples)
+		status.Compliance = 100 * float64(status.GoodSamples) / float64(status.TotalSamples)
+		status.BudgetRemaining = 100 * (1 - badRatio/budget)
+	}
+	status.Met = status.Compliance >= slo.Objective
+
+	if slo.Type == SLOTypeLatency && !status.NoData {
+		value := sloPercentile(samples, slo.Objective)
+		status.Percentile = &value
+	}
+
+	for _, window := range sloBurnWindows {
+		for _, duration := range []time.Duration{window.long, window.short} {
+			status.BurnRates[formatSLOWindow(duration)] = sloBurnRate(samples, slo, at.Add(-duration), budget)
+		}
+	}
+	status.FastBurn = sloWindowBurnRate(status, sloBurnWindows[0]) >= s.config.SLO.FastBurnRate
+	status.SlowBurn = sloWindowBurnRate(status, sloBurnWindows[1]) >= s.config.SLO.SlowBurnRate
+	return status, nil
+}
+
+// syncRules 在监控核心注册或更新SLO的快速、慢速消耗告警规则
+func (s *MonitoringSLOService) syncRules(slo *Models.MonitoringSLO) {
+	if s.core == nil {
+		return
+	}
+	for _, window := range sloBurnWindows {
+		threshold, label := s.config.SLO.FastBurnRate, "快速"
+		if window.name == "slow" {
+			threshold, label = s.config.SLO.SlowBurnRate, "慢速"
+		}
+		rule := &AlertRule{
+			ID:   sloRuleID(slo.ID, window.name),
+			Name: fmt.Sprintf("SLO %s 错误预算%s消耗", slo.Name, label),
+			Description: fmt.Sprintf("%s和%s窗口的错误预算消耗速率均不低于 %s 倍",
+				formatSLOWindow(window.long), formatSLOWindow(window.short), formatReportValue(threshold)),
+			Metric:    sloMetricName(slo.ID, "burn_rate_"+window.name),
+			Condition: ">=",
+			Threshold: threshold,
+			Level:     window.level,
+			Labels:    map[string]string{"slo": slo.Name, "burn": window.name},
+			Enabled:   slo.Enabled,
+		}
+		if err := s.core.AddRule(rule); err != nil {
+			log.Printf("注册SLO告警规则失败: slo=%d, error=%v", slo.ID, err)
+		}
+	}
+}
+
+// applySLOInput 校验参数并写入SLO
+func applySLOInput(slo *Models.MonitoringSLO, input SLOInput) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len(name) > 100 {
+		return fmt.Errorf("%w：名称不能为空且不超过100个字符", ErrInvalidSLO)
+	}
+	metric := strings.TrimSpace(input.Metric)
+	if metric == "" {
+		return fmt.Errorf("%w：指标不能为空", ErrInvalidSLO)
+	}
+	if input.Threshold == nil {
+		return fmt.Errorf("%w：需要合格阈值", ErrInvalidSLO)
+	}
+	if input.Objective <= 0 || input.Objective >= 100 {
+		return fmt.Errorf("%w：目标需要在0到100之间", ErrInvalidSLO)
+	}
+
+	comparison := input.Comparison
+	switch input.Type {
+	case SLOTypeAvailability:
+		if comparison == "" {
+			comparison = ">="
+		}
+		if !containsString([]string{">", ">=", "<", "<="}, comparison) {
+			return fmt.Errorf("%w：不支持的合格条件 %q", ErrInvalidSLO, comparison)
+		}
+	case SLOTypeLatency:
+		if comparison != "" && comparison != "<=" {
+			return fmt.Errorf("%w：延迟类型的合格条件只能是 <=", ErrInvalidSLO)
+		}
+		comparison = "<="
+	default:
+		return fmt.Errorf("%w：不支持的SLO类型 %q", ErrInvalidSLO, input.Type)
+	}
+
+	windowDays := input.WindowDays
+	if windowDays == 0 {
+		windowDays = 30
+	}
+	if windowDays < 1 || windowDays > 90 {
+		return fmt.Errorf("%w：滚动窗口需要在1到90天之间", ErrInvalidSLO)
+	}
+
+	slo.Name = name
+	slo.Description = input.Description
+	slo.Type = input.Type
+	slo.Metric = metric
+	slo.Comparison = comparison
+	slo.Threshold = *input.Threshold
+	slo.Objective = input.Objective
+	slo.WindowDays = windowDays
+	slo.Enabled = input.Enabled == nil || *input.Enabled
+	return nil
+}
+
+// ensureSLONameAvailable 检查SLO名称是否已被其他SLO使用
+func ensureSLONameAvailable(tx *gorm.DB, name string, excludeID uint) error {
+	var count int64
+	if err := tx.Model(&Models.MonitoringSLO{}).Where("name = ? AND id <> ?", name, excludeID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrSLONameExists
+	}
+	return nil
+}
+
+// sloBurnRate 计算 since 之后的预算消耗速率，1表示按目标速率恰好在窗口结束时耗尽预算
+func sloBurnRate(samples []Models.MetricSample, slo *Models.MonitoringSLO, since time.Time, budget float64) float64 {
+	total, bad := 0, 0
+	// 采样按时间升序，从末尾向前统计
+	for i := len(samples) - 1; i >= 0 && !samples[i].Timestamp.Before(since); i-- {
+		total++
+		if !compareAlertValue(samples[i].Value, slo.Comparison, slo.Threshold) {
+			bad++
+		}
+	}
+	if total == 0 || budget <= 0 {
+		return 0
+	}
+	return float64(bad) / float64(total) / budget
+}
+
+// sloWindowBurnRate 多窗口组合的消耗速率，取长短窗口中的较小值
+func sloWindowBurnRate(status *SLOStatus, window sloBurnWindow) float64 {
+	return math.Min(status.BurnRates[formatSLOWindow(window.long)], status.BurnRates[formatSLOWindow(window.short)])
+}
+
+// sloPercentile 计算采样值的分位数（最近秩法），percent 为百分比
+func sloPercentile(samples []Models.MetricSample, percent float64) float64 {
+	values := make([]float64, len(samples))
+	for i, sample := range samples {
+		values[i] = sample.Value
+	}
+	sort.Float64s(values)
+	rank := int(math.Ceil(percent / 100 * float64(len(values))))
+	if rank < 1 {
+		rank = 1
+	}
+	return values[min(rank, len(values))-1]
+}
+
+// sloRuleID SLO消耗告警规则ID
+func sloRuleID(id uint, window string) string {
+	return fmt.Sprintf("slo_%d_%s_burn", id, window)
+}
+
+// sloMetricName SLO上报到监控核心的指标名
+func sloMetricName(id uint, name string) string {
+	return fmt.Sprintf("slo_%d_%s", id, name)
+}
+
+// formatSLOWindow 格式化窗口时长，如 1h、30m
+func formatSLOWindow(duration time.Duration) string {
+	if duration%time.Hour == 0 {
+		return fmt.Sprintf("%dh", duration/time.Hour)
+	}
+	return fmt.Sprintf("%dm", duration/time.Minute)
+}
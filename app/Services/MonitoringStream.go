@@ -0,0 +1,240 @@
+package Services
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 实时监控事件主题
+const (
+	MonitoringStreamTopicMetrics = "metrics" // 每轮采集后的指标值
+	MonitoringStreamTopicAlerts  = "alerts"  // 告警状态变化
+)
+
+// 告警状态变化
+const (
+	AlertTransitionTriggered = "triggered"
+	AlertTransitionResolved  = "resolved"
+)
+
+// monitoringStreamBuffer 每个订阅者的事件缓冲
+const monitoringStreamBuffer = 32
+
+var (
+	ErrUnknownMonitoringTopic   = errors.New("未知的监控事件主题")
+	ErrInvalidMonitoringPattern = errors.New("无效的指标名称匹配模式")
+)
+
+// MonitoringStreamEvent 实时监控事件
+// metrics 主题携带本轮采集的指标值，alerts 主题携带告警快照和状态变化
+type MonitoringStreamEvent struct {
+	ID         uint64             `json:"id"`
+	Topic      string             `json:"topic"`
+	Metrics    map[string]float64 `json:"metrics,omitempty"`
+	Alert      *Alert             `json:"alert,omitempty"`
+	Transition string             `json:"transition,omitempty"`
+	Timestamp  time.Time          `json:"timestamp"`
+}
+
+// MonitoringStreamFilter 订阅过滤条件
+// Topics 为空时订阅全部主题；Metrics 为指标名称匹配模式（支持 * 通配），为空时不过滤，
+// 告警按规则涉及的指标匹配；Visible 不为空时只推送订阅者可见的指标，以及涉及的指标全部可见的告警
+type MonitoringStreamFilter struct {
+	Topics  []string
+	Metrics []string
+	Visible func(metric string) bool
+}
+
+// ParseMonitoringStreamFilter 解析逗号分隔的主题和指标匹配模式
+func ParseMonitoringStreamFilter(topics, metrics string) (MonitoringStreamFilter, error) {
+	var filter MonitoringStreamFilter
+	for _, topic := range splitList(topics) {
+		if topic != MonitoringStreamTopicMetrics && topic != MonitoringStreamTopicAlerts {
+			return filter, ErrUnknownMonitoringTopic
+		}
+		filter.Topics = append(filter.Topics, topic)
+	}
+	for _, pattern := range splitList(metrics) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return filter, ErrInvalidMonitoringPattern
+		}
+		filter.Metrics = append(filter.Metrics, pattern)
+	}
+	return filter, nil
+}
+
+// splitList 拆分逗号分隔的列表，忽略空项
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// HasTopic 是否订阅了主题
+func (f MonitoringStreamFilter) HasTopic(topic string) bool {
+	if len(f.Topics) == 0 {
+		return true
+	}
+	for _, t := range f.Topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+// matchMetric 指标名称是否匹配过滤条件
+func (f MonitoringStreamFilter) matchMetric(name string) bool {
+	if f.Visible != nil && !f.Visible(name) {
+		return false
+	}
+	if len(f.Metrics) == 0 {
+		return true
+	}
+	for _, pattern := range f.Metrics {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply 按过滤条件裁剪事件，不需要推送时返回 false
+// 指标事件只保留匹配的指标，告警事件在规则涉及的任一指标匹配时推送
+func (f MonitoringStreamFilter) Apply(event MonitoringStreamEvent) (MonitoringStreamEvent, bool) {
+	if !f.HasTopic(event.Topic) {
+		return event, false
+	}
+	if len(f.Metrics) == 0 && f.Visible == nil {
+		return event, true
+	}
+	switch event.Topic {
+	case MonitoringStreamTopicMetrics:
+		metrics := make(map[string]float64)
+		for name, value := range event.Metrics {
+			if f.matchMetric(name) {
+				metrics[name] = value
+			}
+		}
+		if len(metrics) == 0 {
+			return event, false
+		}
+		event.Metrics = metrics
+		return event, true
+	case MonitoringStreamTopicAlerts:
+		if event.Alert == nil {
+			return event, false
+		}
+		names := strings.Split(event.Alert.Metric, ",")
+		if f.Visible != nil {
+			for _, name := range names {
+				if !f.Visible(strings.TrimSpace(name)) {
+					return event, false
+				}
+			}
+		}
+		for _, name := range names {
+			if f.matchMetric(name) {
+				return event, true
+			}
+		}
+		return event, false
+	}
+	return event, true
+}
+
+// MonitoringStream 实时监控事件分发
+// 功能说明：
+// 1. 监控核心每轮采集后发布指标值，告警引擎在告警触发和解决时发布状态变化
+// 2. 订阅者按主题和指标名称过滤事件，由控制器以SSE推送给仪表板
+// 3. 订阅者处理不及时时丢弃事件，不阻塞采集和告警评估；下一轮采集会推送完整的最新值
+type MonitoringStream struct {
+	mu          sync.RWMutex
+	subscribers map[chan MonitoringStreamEvent]MonitoringStreamFilter
+	nextID      atomic.Uint64
+	dropped     atomic.Uint64
+}
+
+// NewMonitoringStream 创建实时监控事件分发
+func NewMonitoringStream() *MonitoringStream {
+	return &MonitoringStream{
+		subscribers: make(map[chan MonitoringStreamEvent]MonitoringStreamFilter),
+	}
+}
+
+// Subscribe 按过滤条件订阅事件，ctx 取消后通道关闭
+func (s *MonitoringStream) Subscribe(ctx context.Context, filter MonitoringStreamFilter) <-chan MonitoringStreamEvent {
+	ch := make(chan MonitoringStreamEvent, monitoringStreamBuffer)
+
+	s.mu.Lock()
+	s.subscribers[ch] = filter
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		delete(s.subscribers, ch)
+		close(ch)
+		s.mu.Unlock()
+	}()
+	return ch
+}
+
+// Publish 向匹配的订阅者发布事件，没有订阅者时直接返回
+func (s *MonitoringStream) Publish(event MonitoringStreamEvent) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.subscribers) == 0 {
+		return
+	}
+
+	event.ID = s.nextID.Add(1)
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	for ch, filter := range s.subscribers {
+		filtered, ok := filter.Apply(event)
+		if !ok {
+			continue
+		}
+		select {
+		case ch <- filtered:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// PublishMetrics 发布一轮采集的指标值
+func (s *MonitoringStream) PublishMetrics(values map[string]float64) {
+	if len(values) == 0 {
+		return
+	}
+	s.Publish(MonitoringStreamEvent{Topic: MonitoringStreamTopicMetrics, Metrics: values})
+}
+
+// PublishAlert 发布告警状态变化，alert 为变化时的快照
+func (s *MonitoringStream) PublishAlert(alert Alert, transition string) {
+	alert.transitions = nil
+	s.Publish(MonitoringStreamEvent{Topic: MonitoringStreamTopicAlerts, Alert: &alert, Transition: transition})
+}
+
+// Stats 返回订阅者数量和丢弃的事件数
+func (s *MonitoringStream) Stats() map[string]interface{} {
+	s.mu.RLock()
+	subscribers := len(s.subscribers)
+	s.mu.RUnlock()
+	return map[string]interface{}{
+		"subscribers": subscribers,
+		"dropped":     s.dropped.Load(),
+	}
+}
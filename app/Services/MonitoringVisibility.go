@@ -0,0 +1,188 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"path"
+	"strings"
+	"sync"
+)
+
+// metricSensitivityRule 指标名称匹配模式及其敏感级别
+type metricSensitivityRule struct {
+	pattern string
+	level   int
+}
+
+// MonitoringVisibility 监控数据可见性
+// 功能说明：
+// 1. 指标按名称匹配配置的敏感级别，角色的可见级别不低于指标的敏感级别时才能查看，系统管理员可以查看所有指标
+// 2. 告警涉及的所有指标都可见时告警才可见，告警列表、实时事件流、仪表板组件和告警导出按当前用户裁剪
+// 3. 告警规则的创建者和所属团队的成员始终可见；其他用户只能查看可见范围为 public 且指标可见的规则
+type MonitoringVisibility struct {
+	rules              []metricSensitivityRule
+	clearance          map[string]int
+	defaultSensitivity int
+	defaultClearance   int
+}
+
+// NewMonitoringVisibility 创建监控数据可见性策略
+//
+// config 为 nil 时使用默认值，所有指标为 internal，所有角色可以查看 internal 指标；格式无效的配置项忽略，由配置验证提前拒绝。
+func NewMonitoringVisibility(config *Config.MonitoringVisibilityConfig) *MonitoringVisibility {
+	v := &MonitoringVisibility{
+		clearance:          make(map[string]int),
+		defaultSensitivity: metricSensitivityRank(Config.MetricSensitivityInternal),
+		defaultClearance:   metricSensitivityRank(Config.MetricSensitivityInternal),
+	}
+	if config == nil {
+		return v
+	}
+	if level := metricSensitivityRank(config.DefaultSensitivity); level >= 0 {
+		v.defaultSensitivity = level
+	}
+	if level := metricSensitivityRank(config.DefaultClearance); level >= 0 {
+		v.defaultClearance = level
+	}
+	for _, entry := range splitList(config.MetricSensitivity) {
+		pattern, level, ok := parseVisibilityEntry(entry)
+		if _, err := path.Match(pattern, ""); !ok || err != nil {
+			continue
+		}
+		v.rules = append(v.rules, metricSensitivityRule{pattern: pattern, level: level})
+	}
+	for _, entry := range splitList(config.RoleClearance) {
+		if role, level, ok := parseVisibilityEntry(entry); ok {
+			v.clearance[role] = level
+		}
+	}
+	return v
+}
+
+// Sensitivity 指标的敏感级别，按配置顺序取第一个匹配的模式
+func (v *MonitoringVisibility) Sensitivity(metric string) string {
+	return Config.MetricSensitivityLevels[v.sensitivity(metric)]
+}
+
+// Clearance 角色的可见级别，管理员为最高级别
+func (v *MonitoringVisibility) Clearance(role string) string {
+	return Config.MetricSensitivityLevels[v.roleClearance(role)]
+}
+
+// MetricVisible 指标对用户是否可见
+func (v *MonitoringVisibility) MetricVisible(metric string, viewer DashboardViewer) bool {
+	return v.sensitivity(metric) <= v.roleClearance(viewer.Role)
+}
+
+// VisibleMetrics 过滤出用户可见的指标名称
+func (v *MonitoringVisibility) VisibleMetrics(metrics []string, viewer DashboardViewer) []string {
+	visible := make([]string, 0, len(metrics))
+	for _, metric := range metrics {
+		if v.MetricVisible(metric, viewer) {
+			visible = append(visible, metric)
+		}
+	}
+	return visible
+}
+
+// MetricFilter 返回按用户过滤指标的函数，管理员可以查看所有指标时返回 nil
+func (v *MonitoringVisibility) MetricFilter(viewer DashboardViewer) func(string) bool {
+	if viewer.isAdmin() {
+		return nil
+	}
+	return func(metric string) bool {
+		return v.MetricVisible(metric, viewer)
+	}
+}
+
+// AlertVisible 告警对用户是否可见，组合条件告警的 Metric 为逗号分隔的多个指标，全部可见时才可见
+func (v *MonitoringVisibility) AlertVisible(alert *Alert, viewer DashboardViewer) bool {
+	if alert == nil {
+		return false
+	}
+	for _, metric := range strings.Split(alert.Metric, ",") {
+		if !v.MetricVisible(strings.TrimSpace(metric), viewer) {
+			return false
+		}
+	}
+	return true
+}
+
+// AlertFilter 返回按用户过滤告警的函数，管理员可以查看所有告警时返回 nil
+func (v *MonitoringVisibility) AlertFilter(viewer DashboardViewer) func(*Alert) bool {
+	if viewer.isAdmin() {
+		return nil
+	}
+	return func(alert *Alert) bool {
+		return v.AlertVisible(alert, viewer)
+	}
+}
+
+// RuleVisible 告警规则对用户是否可见
+func (v *MonitoringVisibility) RuleVisible(rule *Models.AlertRule, viewer DashboardViewer) bool {
+	if viewer.isAdmin() || (viewer.UserID != 0 && rule.CreatedBy == viewer.UserID) || viewer.inTeam(rule.TeamID) {
+		return true
+	}
+	return rule.Visibility != Models.AlertRuleVisibilityTeam && v.MetricVisible(rule.MetricName, viewer)
+}
+
+// sensitivity 指标敏感级别的序号
+func (v *MonitoringVisibility) sensitivity(metric string) int {
+	for _, rule := range v.rules {
+		if ok, _ := path.Match(rule.pattern, metric); ok {
+			return rule.level
+		}
+	}
+	return v.defaultSensitivity
+}
+
+// roleClearance 角色可见级别的序号
+func (v *MonitoringVisibility) roleClearance(role string) int {
+	if role == "admin" {
+		return len(Config.MetricSensitivityLevels) - 1
+	}
+	if level, ok := v.clearance[role]; ok {
+		return level
+	}
+	return v.defaultClearance
+}
+
+// parseVisibilityEntry 解析 名称=级别 格式的配置项
+func parseVisibilityEntry(entry string) (string, int, bool) {
+	name, level, found := strings.Cut(entry, "=")
+	name = strings.TrimSpace(name)
+	rank := metricSensitivityRank(strings.TrimSpace(level))
+	return name, rank, found && name != "" && rank >= 0
+}
+
+// metricSensitivityRank 敏感级别的序号，无效的级别返回-1
+func metricSensitivityRank(level string) int {
+	for i, item := range Config.MetricSensitivityLevels {
+		if item == level {
+			return i
+		}
+	}
+	return -1
+}
+
+var (
+	defaultMonitoringVisibility   *MonitoringVisibility
+	defaultMonitoringVisibilityMu sync.RWMutex
+)
+
+// SetDefaultMonitoringVisibility 设置全局监控数据可见性策略
+func SetDefaultMonitoringVisibility(visibility *MonitoringVisibility) {
+	defaultMonitoringVisibilityMu.Lock()
+	defer defaultMonitoringVisibilityMu.Unlock()
+	defaultMonitoringVisibility = visibility
+}
+
+// DefaultMonitoringVisibility 获取全局监控数据可见性策略，未设置时使用默认配置，所有登录用户可以查看所有指标
+func DefaultMonitoringVisibility() *MonitoringVisibility {
+	defaultMonitoringVisibilityMu.RLock()
+	defer defaultMonitoringVisibilityMu.RUnlock()
+	if defaultMonitoringVisibility != nil {
+		return defaultMonitoringVisibility
+	}
+	return NewMonitoringVisibility(nil)
+}
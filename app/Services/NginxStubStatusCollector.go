@@ -0,0 +1,128 @@
+package Services
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// NginxStubStatusCollector nginx stub_status 指标采集器
+// 功能说明：
+// 1. 外部采集器示例：实现 MetricCollector，并通过 CollectorInitializer、CollectorCloser 管理 HTTP 客户端
+// 2. 请求 ngx_http_stub_status_module 输出的状态页，解析连接数和请求计数
+// 3. 指标：nginx_active_connections、nginx_accepts、nginx_handled、nginx_requests、nginx_reading、nginx_writing、nginx_waiting
+//
+// stub_status 输出格式：
+//
+//	Active connections: 291
+//	server accepts handled requests
+//	 16630948 16630948 31070465
+//	Reading: 6 Writing: 179 Waiting: 106
+type NginxStubStatusCollector struct {
+	statusURL string
+	client    *http.Client
+}
+
+// NewNginxStubStatusCollector 创建nginx stub_status 指标采集器
+func NewNginxStubStatusCollector(statusURL string) *NginxStubStatusCollector {
+	return &NginxStubStatusCollector{statusURL: statusURL, client: &http.Client{}}
+}
+
+// Name 采集器名称
+func (c *NginxStubStatusCollector) Name() string {
+	return "nginx"
+}
+
+// Init 校验状态页地址
+// 不在初始化时请求状态页，nginx 晚于应用启动时采集失败只记录在采集器状态中
+func (c *NginxStubStatusCollector) Init(ctx context.Context) error {
+	parsed, err := url.Parse(c.statusURL)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return fmt.Errorf("invalid nginx status URL: %q", c.statusURL)
+	}
+	return nil
+}
+
+// Collect 请求状态页并解析指标，超时由 ctx 控制
+func (c *NginxStubStatusCollector) Collect(ctx context.Context) (map[string]float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.statusURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("nginx status returned %d", resp.StatusCode)
+	}
+	return ParseNginxStubStatus(resp.Body)
+}
+
+// Close 释放空闲连接
+func (c *NginxStubStatusCollector) Close() error {
+	c.client.CloseIdleConnections()
+	return nil
+}
+
+// ParseNginxStubStatus 解析 stub_status 输出
+func ParseNginxStubStatus(r io.Reader) (map[string]float64, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) < 4 {
+		return nil, fmt.Errorf("unexpected nginx stub_status format")
+	}
+
+	metrics := make(map[string]float64)
+	active := strings.TrimPrefix(lines[0], "Active connections:")
+	if active == lines[0] {
+		return nil, fmt.Errorf("unexpected nginx stub_status format: %q", lines[0])
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(active), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid active connections: %w", err)
+	}
+	metrics["nginx_active_connections"] = value
+
+	counters := strings.Fields(lines[2])
+	if len(counters) != 3 {
+		return nil, fmt.Errorf("unexpected nginx stub_status counters: %q", lines[2])
+	}
+	for i, name := range []string{"nginx_accepts", "nginx_handled", "nginx_requests"} {
+		value, err := strconv.ParseFloat(counters[i], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		metrics[name] = value
+	}
+
+	// Reading: 6 Writing: 179 Waiting: 106
+	fields := strings.Fields(lines[3])
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("unexpected nginx stub_status connection states: %q", lines[3])
+	}
+	for i := 0; i < len(fields); i += 2 {
+		name := "nginx_" + strings.ToLower(strings.TrimSuffix(fields[i], ":"))
+		value, err := strconv.ParseFloat(fields[i+1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		metrics[name] = value
+	}
+	return metrics, nil
+}
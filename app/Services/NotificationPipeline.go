@@ -0,0 +1,120 @@
+package Services
+
+import (
+	"log"
+	"sync"
+)
+
+// NotificationPipeline 告警通知管道
+// 功能说明：
+// 1. 统一管理告警通知通道，同名通道只保留一个，后添加的替换先添加的
+// 2. 告警规则引擎、监控集成服务等所有告警来源都通过同一管道发送，共享通道的限流状态
+// 3. 可按通道名称选择发送目标，未指定时发送到全部启用的通道
+// 4. 设置静默判断后，发送前先检查告警是否被静默，被静默的告警（包括恢复通知）不发送到任何通道
+type NotificationPipeline struct {
+	mu       sync.RWMutex
+	channels []NotificationChannel
+	silencer AlertSilencer
+}
+
+// AlertSilencer 告警静默判断，告警被静默时返回命中的静默ID
+type AlertSilencer interface {
+	Silenced(alert MonitoringAlert) (uint, bool)
+}
+
+// NewNotificationPipeline 创建告警通知管道
+func NewNotificationPipeline(channels ...NotificationChannel) *NotificationPipeline {
+	p := &NotificationPipeline{}
+	for _, channel := range channels {
+		p.AddChannel(channel)
+	}
+	return p
+}
+
+// AddChannel 添加通知通道，已有同名通道时替换
+func (p *NotificationPipeline) AddChannel(channel NotificationChannel) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, existing := range p.channels {
+		if existing.GetName() == channel.GetName() {
+			p.channels[i] = channel
+			return
+		}
+	}
+	p.channels = append(p.channels, channel)
+}
+
+// RemoveChannel 移除通知通道
+func (p *NotificationPipeline) RemoveChannel(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, channel := range p.channels {
+		if channel.GetName() == name {
+			p.channels = append(p.channels[:i], p.channels[i+1:]...)
+			return
+		}
+	}
+}
+
+// SetSilencer 设置告警静默判断，为 nil 时不检查静默
+func (p *NotificationPipeline) SetSilencer(silencer AlertSilencer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.silencer = silencer
+}
+
+// Channels 返回已添加的通知通道
+func (p *NotificationPipeline) Channels() []NotificationChannel {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return append([]NotificationChannel(nil), p.channels...)
+}
+
+// Dispatch 同步发送告警，返回按通道名称索引的发送失败
+// names 为空时发送到全部启用的通道，未启用的通道跳过；告警被静默时不发送
+func (p *NotificationPipeline) Dispatch(alert MonitoringAlert, names ...string) map[string]error {
+	failures := make(map[string]error)
+	p.mu.RLock()
+	silencer := p.silencer
+	p.mu.RUnlock()
+	if silencer != nil {
+		if silenceID, silenced := silencer.Silenced(alert); silenced {
+			log.Printf("告警已静默，跳过通知: alert=%s, silence=%d", alert.ID, silenceID)
+			return failures
+		}
+	}
+	for _, channel := range p.Channels() {
+		if !channel.IsEnabled() || !notificationTargeted(channel.GetName(), names) {
+			continue
+		}
+		if err := channel.SendAlert(alert); err != nil {
+			failures[channel.GetName()] = err
+		}
+	}
+	return failures
+}
+
+// Publish 异步发送告警，发送失败只记录日志
+func (p *NotificationPipeline) Publish(alert MonitoringAlert, names ...string) {
+	go func() {
+		for name, err := range p.Dispatch(alert, names...) {
+			log.Printf("发送告警通知失败: channel=%s, alert=%s, error=%v", name, alert.ID, err)
+		}
+	}()
+}
+
+// notificationTargeted 通道是否在发送目标中
+func notificationTargeted(name string, names []string) bool {
+	if len(names) == 0 {
+		return true
+	}
+	for _, target := range names {
+		if target == name {
+			return true
+		}
+	}
+	return false
+}
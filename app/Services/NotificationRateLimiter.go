@@ -0,0 +1,84 @@
+package Services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// NotificationRateLimitError 通知通道触发限流
+// 本地限流或服务商返回限流时返回该错误，RetryAfter 为建议的等待时间
+type NotificationRateLimitError struct {
+	Channel    string
+	Target     string
+	RetryAfter time.Duration
+}
+
+func (e *NotificationRateLimitError) Error() string {
+	return fmt.Sprintf("%s通知发送过于频繁（%s），请在%v后重试", e.Channel, e.Target, e.RetryAfter.Round(time.Second))
+}
+
+// notificationRateLimiter 通知通道的滑动窗口限流器
+// 功能说明：
+// 1. 按发送目标（会话ID、机器人地址）分别计数，窗口内超过 limit 条时拒绝发送
+// 2. 服务商返回限流时调用 Block 暂停该目标，在服务商要求的时间内不再请求
+// 3. limit 小于等于 0 时不限流
+type notificationRateLimiter struct {
+	mu           sync.Mutex
+	limit        int
+	window       time.Duration
+	sent         map[string][]time.Time
+	blockedUntil map[string]time.Time
+	now          func() time.Time
+}
+
+func newNotificationRateLimiter(limit int, window time.Duration) *notificationRateLimiter {
+	return &notificationRateLimiter{
+		limit:        limit,
+		window:       window,
+		sent:         make(map[string][]time.Time),
+		blockedUntil: make(map[string]time.Time),
+		now:          time.Now,
+	}
+}
+
+// Allow 检查并占用一次发送额度，不允许时返回需要等待的时间
+func (l *notificationRateLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if until, ok := l.blockedUntil[key]; ok {
+		if now.Before(until) {
+			return false, until.Sub(now)
+		}
+		delete(l.blockedUntil, key)
+	}
+	if l.limit <= 0 {
+		return true, 0
+	}
+
+	cutoff := now.Add(-l.window)
+	sent := l.sent[key]
+	i := 0
+	for i < len(sent) && !sent[i].After(cutoff) {
+		i++
+	}
+	sent = sent[i:]
+	if len(sent) >= l.limit {
+		l.sent[key] = sent
+		return false, sent[0].Add(l.window).Sub(now)
+	}
+	l.sent[key] = append(sent, now)
+	return true, 0
+}
+
+// Block 在 duration 内暂停向该目标发送
+func (l *notificationRateLimiter) Block(key string, duration time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	until := l.now().Add(duration)
+	if current, ok := l.blockedUntil[key]; !ok || until.After(current) {
+		l.blockedUntil[key] = until
+	}
+}
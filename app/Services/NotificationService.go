@@ -0,0 +1,367 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotificationNotFound 通知不存在或不属于当前用户
+var ErrNotificationNotFound = errors.New("通知不存在")
+
+// 推送事件类型
+const (
+	NotificationEventCreated     = "notification" // 新通知
+	NotificationEventUnreadCount = "unread_count" // 未读数变化（标记已读、删除）
+)
+
+// NotificationEvent 推送给在线用户的通知事件
+type NotificationEvent struct {
+	Type         string               `json:"type"`
+	Notification *Models.Notification `json:"notification,omitempty"`
+	UnreadCount  int64                `json:"unread_count"`
+}
+
+// NotificationFilter 通知查询条件
+type NotificationFilter struct {
+	Type       string
+	UnreadOnly bool
+	Page       int
+	Limit      int
+}
+
+// NotificationService 站内通知服务
+// 功能说明：
+// 1. 系统事件（备份完成、安全告警、密码即将过期等）按接收用户生成通知
+// 2. 用户查询通知列表、未读数，标记已读和删除
+// 3. 在线用户通过 Subscribe 订阅新通知和未读数变化，由控制器以SSE或WebSocket推送
+// 4. 同类型同来源的安全告警在冷却时间内只通知一次，超过保留天数的通知定期清理
+type NotificationService struct {
+	db     *gorm.DB
+	config *Config.NotificationConfig
+
+	mu          sync.RWMutex
+	subscribers map[uint]map[chan NotificationEvent]struct{}
+
+	alertMu   sync.Mutex
+	lastAlert map[string]time.Time
+}
+
+// NewNotificationService 创建站内通知服务
+//
+// config 为 nil 时使用全局配置。
+func NewNotificationService(db *gorm.DB, config *Config.NotificationConfig) *NotificationService {
+	if config == nil {
+		if globalConfig := Config.GetConfig(); globalConfig != nil {
+			config = &globalConfig.Notification
+		} else {
+			config = &Config.NotificationConfig{
+				Enabled:               true,
+				PushEnabled:           true,
+				RetentionDays:         90,
+				SecurityAlertLevels:   []string{"high", "critical"},
+				SecurityAlertCooldown: 10 * time.Minute,
+			}
+		}
+	}
+
+	return &NotificationService{
+		db:          db,
+		config:      config,
+		subscribers: make(map[uint]map[chan NotificationEvent]struct{}),
+		lastAlert:   make(map[string]time.Time),
+	}
+}
+
+// GetConfig 获取通知配置
+func (s *NotificationService) GetConfig() *Config.NotificationConfig {
+	return s.config
+}
+
+// Notify 向指定用户发送通知
+func (s *NotificationService) Notify(userID uint, notificationType, title, content string, data map[string]interface{}) (*Models.Notification, error) {
+	notifications, err := s.NotifyUsers([]uint{userID}, notificationType, title, content, data)
+	if err != nil || len(notifications) == 0 {
+		return nil, err
+	}
+	return notifications[0], nil
+}
+
+// NotifyUsers 向多个用户发送同一条通知，未启用站内通知时不生成
+func (s *NotificationService) NotifyUsers(userIDs []uint, notificationType, title, content string, data map[string]interface{}) ([]*Models.Notification, error) {
+	if !s.config.Enabled || len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	notifications := make([]*Models.Notification, 0, len(userIDs))
+	for _, userID := range userIDs {
+		notifications = append(notifications, &Models.Notification{
+			UserID:  userID,
+			Type:    notificationType,
+			Title:   title,
+			Content: content,
+			Data:    data,
+		})
+	}
+	if err := s.db.Create(&notifications).Error; err != nil {
+		return nil, err
+	}
+
+	for _, notification := range notifications {
+		if s.hasSubscribers(notification.UserID) {
+			s.publish(notification.UserID, NotificationEvent{Type: NotificationEventCreated, Notification: notification})
+		}
+	}
+	return notifications, nil
+}
+
+// NotifyAdmins 向所有启用状态的管理员发送通知
+func (s *NotificationService) NotifyAdmins(notificationType, title, content string, data map[string]interface{}) (int, error) {
+	if !s.config.Enabled {
+		return 0, nil
+	}
+
+	var adminIDs []uint
+	if err := s.db.Model(&Models.User{}).Where("role = ? AND status = ?", "admin", 1).Pluck("id", &adminIDs).Error; err != nil {
+		return 0, err
+	}
+	notifications, err := s.NotifyUsers(adminIDs, notificationType, title, content, data)
+	return len(notifications), err
+}
+
+// NotifySecurityAlert 安全事件需要告警时通知管理员，同类型同来源在冷却时间内只通知一次
+//
+// 返回是否发送了通知。
+func (s *NotificationService) NotifySecurityAlert(event *Models.SecurityEvent) bool {
+	if !s.config.Enabled || (!event.Alerted && !s.config.IsSecurityAlertLevel(event.EventLevel)) {
+		return false
+	}
+
+	key := event.EventType + "|" + event.IPAddress
+	now := time.Now()
+	s.alertMu.Lock()
+	if last, ok := s.lastAlert[key]; ok && now.Sub(last) < s.config.SecurityAlertCooldown {
+		s.alertMu.Unlock()
+		return false
+	}
+	s.lastAlert[key] = now
+	// 清理过期的冷却记录，避免大量来源IP时无限增长
+	for k, last := range s.lastAlert {
+		if now.Sub(last) >= s.config.SecurityAlertCooldown {
+			delete(s.lastAlert, k)
+		}
+	}
+	s.alertMu.Unlock()
+
+	content := "事件类型: " + event.EventType + "，级别: " + event.EventLevel
+	if event.IPAddress != "" {
+		content += "，来源IP: " + event.IPAddress
+	}
+	if event.Details != "" {
+		content += "。" + event.Details
+	}
+	data := map[string]interface{}{
+		"event_id":   event.ID,
+		"event_type": event.EventType,
+		"level":      event.EventLevel,
+		"ip_address": event.IPAddress,
+		"risk_score": event.RiskScore,
+	}
+	if event.UserID != nil {
+		data["user_id"] = *event.UserID
+	}
+
+	if _, err := s.NotifyAdmins(Models.NotificationTypeSecurityAlert, "安全告警: "+event.EventType, content, data); err != nil {
+		log.Printf("发送安全告警通知失败: %v", err)
+		return false
+	}
+	return true
+}
+
+// List 查询用户的通知
+func (s *NotificationService) List(userID uint, filter NotificationFilter) ([]Models.Notification, int64, error) {
+	query := s.db.Model(&Models.Notification{}).Where("user_id = ?", userID)
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.UnreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 20
+	}
+	var notifications []Models.Notification
+	err := query.Order("created_at DESC, id DESC").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&notifications).Error
+	return notifications, total, err
+}
+
+// UnreadCount 用户未读通知数
+func (s *NotificationService) UnreadCount(userID uint) (int64, error) {
+	var count int64
+	err := s.db.Model(&Models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// MarkRead 标记单条通知已读，已读的通知保持原阅读时间
+func (s *NotificationService) MarkRead(userID, id uint) (*Models.Notification, error) {
+	var notification Models.Notification
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&notification).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotificationNotFound
+		}
+		return nil, err
+	}
+	if notification.ReadAt != nil {
+		return &notification, nil
+	}
+
+	now := time.Now()
+	result := s.db.Model(&Models.Notification{}).Where("id = ? AND read_at IS NULL", id).Update("read_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	notification.ReadAt = &now
+	if result.RowsAffected > 0 {
+		s.publishUnreadCount(userID)
+	}
+	return &notification, nil
+}
+
+// MarkAllRead 标记用户全部通知已读，可按类型限定，返回标记数量
+func (s *NotificationService) MarkAllRead(userID uint, notificationType string) (int64, error) {
+	query := s.db.Model(&Models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID)
+	if notificationType != "" {
+		query = query.Where("type = ?", notificationType)
+	}
+	result := query.Update("read_at", time.Now())
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected > 0 {
+		s.publishUnreadCount(userID)
+	}
+	return result.RowsAffected, nil
+}
+
+// Delete 删除用户的通知
+func (s *NotificationService) Delete(userID, id uint) error {
+	result := s.db.Where("id = ? AND user_id = ?", id, userID).Delete(&Models.Notification{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotificationNotFound
+	}
+	s.publishUnreadCount(userID)
+	return nil
+}
+
+// Cleanup 分批删除超过保留天数的通知，返回删除数量，由清理调度器定期运行
+func (s *NotificationService) Cleanup(ctx context.Context) (int64, error) {
+	if s.config.RetentionDays <= 0 {
+		return 0, nil
+	}
+	cutoff := time.Now().AddDate(0, 0, -s.config.RetentionDays)
+	return BatchDelete(ctx, s.db.Where("created_at < ?", cutoff), &Models.Notification{})
+}
+
+// Subscribe 订阅用户的通知事件，ctx 取消后通道关闭
+//
+// 订阅者处理不及时时丢弃事件，客户端可通过未读数接口重新同步。
+func (s *NotificationService) Subscribe(ctx context.Context, userID uint) <-chan NotificationEvent {
+	ch := make(chan NotificationEvent, 16)
+
+	s.mu.Lock()
+	if s.subscribers[userID] == nil {
+		s.subscribers[userID] = make(map[chan NotificationEvent]struct{})
+	}
+	s.subscribers[userID][ch] = struct{}{}
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		delete(s.subscribers[userID], ch)
+		if len(s.subscribers[userID]) == 0 {
+			delete(s.subscribers, userID)
+		}
+		close(ch)
+		s.mu.Unlock()
+	}()
+	return ch
+}
+
+// OnlineSubscribers 当前订阅通知的用户数
+func (s *NotificationService) OnlineSubscribers() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.subscribers)
+}
+
+func (s *NotificationService) hasSubscribers(userID uint) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.subscribers[userID]) > 0
+}
+
+func (s *NotificationService) publishUnreadCount(userID uint) {
+	if !s.hasSubscribers(userID) {
+		return
+	}
+	s.publish(userID, NotificationEvent{Type: NotificationEventUnreadCount})
+}
+
+// publish 推送事件，附带最新未读数
+func (s *NotificationService) publish(userID uint, event NotificationEvent) {
+	count, err := s.UnreadCount(userID)
+	if err != nil {
+		log.Printf("查询未读通知数失败: user=%d, error=%v", userID, err)
+	}
+	event.UnreadCount = count
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for ch := range s.subscribers[userID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+var (
+	defaultNotificationService   *NotificationService
+	defaultNotificationServiceMu sync.RWMutex
+)
+
+// SetDefaultNotificationService 设置全局站内通知服务，备份、安全、密码过期等系统事件通过它生成通知
+func SetDefaultNotificationService(service *NotificationService) {
+	defaultNotificationServiceMu.Lock()
+	defer defaultNotificationServiceMu.Unlock()
+	defaultNotificationService = service
+}
+
+// DefaultNotificationService 获取全局站内通知服务，未设置时返回 nil
+func DefaultNotificationService() *NotificationService {
+	defaultNotificationServiceMu.RLock()
+	defer defaultNotificationServiceMu.RUnlock()
+	return defaultNotificationService
+}
@@ -1,14 +1,19 @@
 package Services
 
 import (
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Utils"
 	"context"
 	"fmt"
-	"runtime"
+	"log"
+	"sort"
 	"sync"
 	"time"
 )
 
 // OptimizedMonitoringService 优化的监控服务
+// 兼容外观：指标采集、阈值告警和告警查询委托给统一监控核心（MonitoringCore），
+// 本服务只负责按配置注册采集器和阈值规则，并缓存系统、应用指标供接口查询
 type OptimizedMonitoringService struct {
 	*ServiceBase
 
@@ -35,6 +40,23 @@ type OptimizedMonitoringService struct {
 	flushInterval time.Duration
 	metricsBuffer []MetricData
 	bufferMutex   sync.Mutex
+
+	// 指标历史，设置后刷新时保存数值型指标
+	metricHistory *MetricHistoryService
+	// 指标批量写入器，设置后数值型指标经写入队列批量保存
+	batchWriter *MetricBatchWriter
+
+	// 统一监控核心
+	core *MonitoringCore
+
+	// 附加到每个指标的默认标签，如Kubernetes部署标签
+	defaultTags map[string]string
+
+	// 指标超过该时长未更新时在响应中标记为过期
+	staleAfter time.Duration
+
+	// 指标基数保护，设置后自定义指标的标签先经过校验和序列数限制
+	cardinalityGuard *MetricCardinalityGuard
 }
 
 // MonitoringConfig 监控配置
@@ -67,6 +89,8 @@ type SystemMetrics struct {
 	Goroutines  int       `json:"goroutines"`
 	HeapSize    uint64    `json:"heap_size"`
 	GCCount     uint32    `json:"gc_count"`
+	CPULimit    float64   `json:"cpu_limit,omitempty"`    // 容器CPU配额（核），识别到cgroup且设置了配额时有值
+	MemoryLimit uint64    `json:"memory_limit,omitempty"` // 容器内存限额（字节），识别到cgroup且设置了限额时有值
 	Timestamp   time.Time `json:"timestamp"`
 }
 
@@ -95,6 +119,7 @@ func NewOptimizedMonitoringService() *OptimizedMonitoringService {
 		batchSize:     100,
 		flushInterval: 5 * time.Minute,
 		metricsBuffer: make([]MetricData, 0, 100),
+		staleAfter:    defaultMetricStaleAfter,
 		config: &MonitoringConfig{
 			Enabled:               true,
 			CheckInterval:         30 * time.Second,
@@ -109,12 +134,63 @@ func NewOptimizedMonitoringService() *OptimizedMonitoringService {
 		},
 	}
 
+	service.SetMonitoringCore(DefaultMonitoringCore())
+
 	// 注册到全局服务管理器
 	RegisterGlobalService("optimized_monitoring_service", service)
 
 	return service
 }
 
+// SetMonitoringCore 设置监控核心，并按当前配置注册采集器和阈值规则
+func (s *OptimizedMonitoringService) SetMonitoringCore(core *MonitoringCore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.core = core
+	s.registerWithCore()
+}
+
+// MonitoringCore 返回监控核心
+func (s *OptimizedMonitoringService) MonitoringCore() *MonitoringCore {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.core
+}
+
+// registerWithCore 按配置注册采集器和阈值规则，调用方需持有 s.mu
+// 系统指标使用核心的运行时采集器，应用指标由本服务的计数提供
+func (s *OptimizedMonitoringService) registerWithCore() {
+	if s.config.EnableSystemMetrics {
+		if err := s.core.RegisterCollector(NewRuntimeMetricsCollector()); err != nil {
+			log.Printf("注册采集器失败: collector=runtime, error=%v", err)
+		}
+	} else {
+		s.core.UnregisterCollector("runtime")
+	}
+	if s.config.EnableAppMetrics {
+		if err := s.core.RegisterCollector(NewMetricCollector("application", s.collectAppValues)); err != nil {
+			log.Printf("注册采集器失败: collector=application, error=%v", err)
+		}
+	} else {
+		s.core.UnregisterCollector("application")
+	}
+
+	thresholds := []struct {
+		metric    string
+		threshold float64
+	}{
+		{"memory_usage", s.config.MaxMemoryUsage},
+		{"cpu_usage", s.config.MaxCPUUsage},
+		{"goroutines", 10000},
+	}
+	for _, t := range thresholds {
+		if err := s.core.SetThreshold(t.metric, ">", t.threshold, AlertLevelWarning); err != nil {
+			log.Printf("注册阈值规则失败: metric=%s, error=%v", t.metric, err)
+		}
+	}
+}
+
 // Start 启动监控服务
 func (s *OptimizedMonitoringService) Start() error {
 	s.mu.Lock()
@@ -188,112 +264,64 @@ func (s *OptimizedMonitoringService) flushLoop() {
 // collectMetrics 收集指标
 //
 // 功能说明：
-// 1. 根据配置收集不同类型的指标
-// 2. 支持系统指标、应用指标、业务指标的独立控制
-// 3. 定期调用，用于持续监控系统状态
-//
-// 指标类型：
-// - 系统指标：CPU、内存、Goroutine、GC等系统资源使用情况
-// - 应用指标：HTTP请求、数据库查询、缓存命中率等应用性能指标
-// - 业务指标：用户注册数、订单数、收入等业务相关指标
-//
-// 配置控制：
-// - 每种指标类型都可以通过配置独立启用/禁用
-// - 可以根据实际需求选择收集的指标类型
-// - 禁用不需要的指标可以减少性能开销
-//
-// 调用时机：
-// - 由monitoringLoop定期调用
-// - 调用频率由checkInterval配置决定
-// - 默认每30秒收集一次
-//
-// 性能考虑：
-// - 指标收集是轻量级操作，但频繁收集可能影响性能
-// - 可以根据实际情况调整收集频率
-// - 某些指标收集可能需要系统调用，可能较慢
+// 1. 由监控核心执行全部已注册的采集器，并评估告警规则
+// 2. 系统指标和应用指标按配置独立启用，缓存后供接口查询
+// 3. 由monitoringLoop定期调用，默认每30秒一次
 //
 // 注意事项：
-// - 指标收集失败不应该影响主流程
-// - 收集的指标会缓存，由flushLoop定期刷新到存储
-// - 大量指标可能导致内存占用增加
+// - 指标收集和告警评估失败不应该影响主流程
+// - 业务指标可通过向监控核心注册采集器接入
 func (s *OptimizedMonitoringService) collectMetrics() {
-	// 收集系统指标（如果启用）
-	// 包括CPU、内存、Goroutine、GC等系统资源使用情况
+	// Start 持有 s.mu 时也会调用，这里直接读取 s.core
+	values := s.core.Collect()
+	if err := s.core.CheckAlerts(); err != nil {
+		log.Printf("告警评估失败: %v", err)
+	}
+
+	// 系统指标：CPU、内存、Goroutine、GC等系统资源使用情况
 	if s.config.EnableSystemMetrics {
-		s.collectSystemMetrics()
+		s.collectSystemMetrics(values)
 	}
 
-	// 收集应用指标（如果启用）
-	// 包括HTTP请求、数据库查询、缓存命中率等应用性能指标
+	// 应用指标：HTTP请求、数据库查询、缓存命中率等应用性能指标
 	if s.config.EnableAppMetrics {
 		s.collectAppMetrics()
 	}
 
-	// 收集业务指标（如果启用）
-	// 包括用户注册数、订单数、收入等业务相关指标
+	// 业务指标：用户注册数、订单数、收入等业务相关指标
 	if s.config.EnableBusinessMetrics {
 		s.collectBusinessMetrics()
 	}
 }
 
-// collectSystemMetrics 收集系统指标
-//
-// 功能说明：
-// 1. 收集Go运行时的系统资源使用情况
-// 2. 包括CPU使用率、内存使用率、Goroutine数量等
-// 3. 缓存指标并检查是否超过阈值
-//
-// 收集的指标：
-// - CPUUsage: CPU使用率（百分比）
-// - MemoryUsage: 内存使用率（百分比）
-// - Goroutines: 当前Goroutine数量
-// - HeapSize: 堆内存分配大小（字节）
-// - GCCount: GC执行次数
-// - Timestamp: 指标收集时间戳
-//
-// 数据来源：
-// - runtime.ReadMemStats(): 获取Go运行时内存统计
-// - runtime.NumGoroutine(): 获取当前Goroutine数量
-// - getCPUUsage(): 获取CPU使用率（需要系统调用）
-// - getMemoryUsage(): 获取内存使用率（需要系统调用）
-//
-// 阈值检查：
-// - 检查指标是否超过配置的阈值
-// - 超过阈值时触发告警
-// - 用于及时发现系统资源问题
-//
-// 性能考虑：
-// - runtime.ReadMemStats()是STW（Stop The World）操作，可能影响性能
-// - 建议不要过于频繁调用（默认30秒一次）
-// - CPU和内存使用率获取可能需要系统调用，可能较慢
-//
-// 注意事项：
-// - 指标收集失败不应该影响主流程
-// - 某些指标（如CPU使用率）可能需要系统权限
-// - 内存统计是Go运行时的统计，不是系统级别的
-func (s *OptimizedMonitoringService) collectSystemMetrics() {
-	// 读取Go运行时内存统计
-	// 注意：这是STW操作，可能影响性能，不要过于频繁调用
-	var m runtime.MemStats
-	runtime.ReadMemStats(&m)
-
-	// 构建系统指标对象
+// collectSystemMetrics 缓存运行时采集器的系统指标
+// 识别到cgroup时 cpu_usage、memory_usage 已由容器资源采集器按容器限额计算
+func (s *OptimizedMonitoringService) collectSystemMetrics(values map[string]float64) {
 	metrics := SystemMetrics{
-		CPUUsage:    s.getCPUUsage(),        // CPU使用率（需要系统调用）
-		MemoryUsage: s.getMemoryUsage(),     // 内存使用率（需要系统调用）
-		Goroutines:  runtime.NumGoroutine(), // 当前Goroutine数量
-		HeapSize:    m.HeapAlloc,            // 堆内存分配大小
-		GCCount:     m.NumGC,                // GC执行次数
-		Timestamp:   time.Now(),             // 指标收集时间戳
+		CPUUsage:    values["cpu_usage"],
+		MemoryUsage: values["memory_usage"],
+		Goroutines:  int(values["goroutines"]),
+		HeapSize:    uint64(values["heap_size"]),
+		GCCount:     uint32(values["gc_count"]),
+		CPULimit:    values["container_cpu_limit_cores"],
+		MemoryLimit: uint64(values["container_memory_limit_bytes"]),
+		Timestamp:   time.Now(),
 	}
 
-	// 缓存指标
 	// 指标会先缓存到内存，由flushLoop定期刷新到存储
 	s.cacheMetrics("system", metrics)
 
-	// 检查阈值
-	// 如果指标超过配置的阈值，触发告警
-	s.checkThresholds(metrics)
+	// 系统指标同时经flushLoop写入指标历史，最近采样缓冲区为空（如刚重启）时接口从历史读取
+	// Start 持有 s.mu 时也会调用，这里不经过 AddMetric 读取默认标签
+	samples := make([]MetricData, 0, len(systemMetricNames))
+	for _, name := range systemMetricNames {
+		if value, exists := values[name]; exists {
+			samples = append(samples, MetricData{Name: name, Value: value, Timestamp: metrics.Timestamp})
+		}
+	}
+	s.bufferMutex.Lock()
+	s.metricsBuffer = append(s.metricsBuffer, samples...)
+	s.bufferMutex.Unlock()
 }
 
 // collectAppMetrics 收集应用指标
@@ -351,42 +379,25 @@ func (s *OptimizedMonitoringService) collectAppMetrics() {
 	s.cacheMetrics("app", metrics)
 }
 
+// collectAppValues 应用指标采集器，时长单位为毫秒
+func (s *OptimizedMonitoringService) collectAppValues(ctx context.Context) (map[string]float64, error) {
+	return map[string]float64{
+		"request_count":        float64(s.getRequestCount()),
+		"avg_response_time_ms": float64(s.getAverageResponseTime()) / float64(time.Millisecond),
+		"error_count":          float64(s.getErrorCount()),
+		"active_users":         float64(s.getActiveUsers()),
+		"database_queries":     float64(s.getDatabaseQueries()),
+		"cache_hits":           float64(s.getCacheHits()),
+		"cache_misses":         float64(s.getCacheMisses()),
+	}, nil
+}
+
 // collectBusinessMetrics 收集业务指标
 func (s *OptimizedMonitoringService) collectBusinessMetrics() {
 	// 这里可以添加具体的业务指标收集逻辑
 	// 例如：用户注册数、订单数、收入等
 }
 
-// getCPUUsage 获取CPU使用率
-func (s *OptimizedMonitoringService) getCPUUsage() float64 {
-	// 使用runtime包获取基本的CPU使用率信息
-	var m runtime.MemStats
-	runtime.ReadMemStats(&m)
-
-	// 简化的CPU使用率计算
-	// 基于GC频率和内存分配来估算CPU使用率
-	gcCount := m.NumGC
-	if gcCount > 0 {
-		// 基于GC频率估算CPU使用率（这是一个简化的方法）
-		// 在实际应用中，应该使用专门的CPU监控库
-		return float64(gcCount) * 0.1 // 简化的计算
-	}
-
-	return 0.0
-}
-
-// getMemoryUsage 获取内存使用率
-func (s *OptimizedMonitoringService) getMemoryUsage() float64 {
-	var m runtime.MemStats
-	runtime.ReadMemStats(&m)
-
-	// 计算内存使用率
-	totalMemory := uint64(1024 * 1024 * 1024) // 假设1GB总内存
-	usedMemory := m.HeapAlloc
-
-	return float64(usedMemory) / float64(totalMemory) * 100
-}
-
 // getRequestCount 获取请求数
 func (s *OptimizedMonitoringService) getRequestCount() int64 {
 	// 从缓存中获取请求计数
@@ -456,31 +467,6 @@ func (s *OptimizedMonitoringService) getCachedMetrics(key string) (interface{},
 	return data, exists
 }
 
-// checkThresholds 检查阈值
-func (s *OptimizedMonitoringService) checkThresholds(metrics SystemMetrics) {
-	// 检查内存使用率
-	if metrics.MemoryUsage > s.config.MaxMemoryUsage {
-		s.alert("high_memory_usage", fmt.Sprintf("内存使用率过高: %.2f%%", metrics.MemoryUsage))
-	}
-
-	// 检查CPU使用率
-	if metrics.CPUUsage > s.config.MaxCPUUsage {
-		s.alert("high_cpu_usage", fmt.Sprintf("CPU使用率过高: %.2f%%", metrics.CPUUsage))
-	}
-
-	// 检查Goroutine数量
-	if metrics.Goroutines > 10000 {
-		s.alert("high_goroutine_count", fmt.Sprintf("Goroutine数量过多: %d", metrics.Goroutines))
-	}
-}
-
-// alert 发送告警
-func (s *OptimizedMonitoringService) alert(alertType, message string) {
-	// 这里应该实现实际的告警逻辑
-	// 例如：发送邮件、短信、推送到监控系统等
-	fmt.Printf("告警: %s - %s\n", alertType, message)
-}
-
 // flushMetrics 刷新指标
 func (s *OptimizedMonitoringService) flushMetrics() {
 	s.bufferMutex.Lock()
@@ -497,15 +483,66 @@ func (s *OptimizedMonitoringService) flushMetrics() {
 	s.metricsBuffer = s.metricsBuffer[:0]
 }
 
+// SetMetricHistoryService 设置指标历史服务，用于动态阈值告警和规则回测
+func (s *OptimizedMonitoringService) SetMetricHistoryService(history *MetricHistoryService) {
+	s.bufferMutex.Lock()
+	defer s.bufferMutex.Unlock()
+	s.metricHistory = history
+}
+
+// SetMetricBatchWriter 设置指标批量写入器，设置后不再直接写入指标历史
+func (s *OptimizedMonitoringService) SetMetricBatchWriter(writer *MetricBatchWriter) {
+	s.bufferMutex.Lock()
+	defer s.bufferMutex.Unlock()
+	s.batchWriter = writer
+}
+
+// SetCardinalityGuard 设置指标基数保护
+func (s *OptimizedMonitoringService) SetCardinalityGuard(guard *MetricCardinalityGuard) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cardinalityGuard = guard
+}
+
+// CardinalityGuard 返回指标基数保护，未设置时返回nil
+func (s *OptimizedMonitoringService) CardinalityGuard() *MetricCardinalityGuard {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cardinalityGuard
+}
+
 // processMetricsBatch 处理指标批次
+// 设置了批量写入器或指标历史服务时保存数值型指标，非数值指标忽略
 func (s *OptimizedMonitoringService) processMetricsBatch(metrics []MetricData) {
-	// 这里应该实现实际的指标处理逻辑
-	// 例如：存储到数据库、发送到监控系统等
-	fmt.Printf("处理 %d 个指标\n", len(metrics))
+	if s.metricHistory == nil && s.batchWriter == nil {
+		return
+	}
+
+	samples := make([]Models.MetricSample, 0, len(metrics))
+	for _, metric := range metrics {
+		if value, ok := metricSampleValue(metric.Value); ok {
+			samples = append(samples, Models.MetricSample{Name: metric.Name, Value: value, Timestamp: metric.Timestamp})
+		}
+	}
+	if s.batchWriter != nil {
+		s.batchWriter.Write(samples)
+		return
+	}
+	if err := s.metricHistory.RecordBatch(samples); err != nil {
+		log.Printf("保存指标历史失败: %v", err)
+	}
 }
 
 // AddMetric 添加指标
 func (s *OptimizedMonitoringService) AddMetric(name string, value interface{}, tags map[string]string) {
+	if defaults := s.DefaultTags(); len(defaults) > 0 {
+		merged := defaults
+		for key, tag := range tags {
+			merged[key] = tag
+		}
+		tags = merged
+	}
+
 	metric := MetricData{
 		Name:      name,
 		Value:     value,
@@ -526,6 +563,28 @@ func (s *OptimizedMonitoringService) AddMetric(name string, value interface{}, t
 	}
 }
 
+// SetDefaultTags 设置附加到每个指标的默认标签，AddMetric 传入的同名标签优先
+func (s *OptimizedMonitoringService) SetDefaultTags(tags map[string]string) {
+	defaults := make(map[string]string, len(tags))
+	for key, tag := range tags {
+		defaults[key] = tag
+	}
+	s.mu.Lock()
+	s.defaultTags = defaults
+	s.mu.Unlock()
+}
+
+// DefaultTags 返回默认标签的副本
+func (s *OptimizedMonitoringService) DefaultTags() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tags := make(map[string]string, len(s.defaultTags))
+	for key, tag := range s.defaultTags {
+		tags[key] = tag
+	}
+	return tags
+}
+
 // GetMetrics 获取指标
 func (s *OptimizedMonitoringService) GetMetrics(metricType string) (interface{}, error) {
 	data, exists := s.getCachedMetrics(metricType)
@@ -536,19 +595,103 @@ func (s *OptimizedMonitoringService) GetMetrics(metricType string) (interface{},
 	return data, nil
 }
 
+// systemMetricNames 组成系统指标的监控核心指标名
+var systemMetricNames = []string{
+	"cpu_usage",
+	"memory_usage",
+	"goroutines",
+	"heap_size",
+	"gc_count",
+	"container_cpu_limit_cores",
+	"container_memory_limit_bytes",
+}
+
 // GetSystemMetrics 获取系统指标
 func (s *OptimizedMonitoringService) GetSystemMetrics() (*SystemMetrics, error) {
-	data, exists := s.getCachedMetrics("system")
-	if !exists {
-		return nil, fmt.Errorf("系统指标不存在")
+	metrics, _, err := s.currentSystemMetrics()
+	return metrics, err
+}
+
+// currentSystemMetrics 获取系统指标及其来源和新鲜度
+// 功能说明：
+// 1. 优先读取监控核心最近采样缓冲区中各指标的最新值，不查询数据库
+// 2. 缓冲区为空（如刚启动尚未完成首轮采集）时回退到指标历史中的最近采样
+// 3. 最近采样超过过期时间（MONITORING_REALTIME_STALE_AFTER）未更新时标记为过期
+func (s *OptimizedMonitoringService) currentSystemMetrics() (*SystemMetrics, MetricFreshness, error) {
+	now := time.Now()
+	samples, source, err := s.latestSystemSamples()
+	if err != nil {
+		return nil, newMetricFreshness(MetricSourceNone, time.Time{}, now, 0), fmt.Errorf("查询系统指标失败: %v", err)
 	}
 
-	metrics, ok := data.(SystemMetrics)
-	if !ok {
-		return nil, fmt.Errorf("系统指标类型错误")
+	// 按最久未更新的指标计算新鲜度，任一指标过期即视为过期
+	var updatedAt time.Time
+	for _, sample := range samples {
+		if updatedAt.IsZero() || sample.Timestamp.Before(updatedAt) {
+			updatedAt = sample.Timestamp
+		}
+	}
+	freshness := newMetricFreshness(source, updatedAt, now, s.StaleAfter())
+	if len(samples) == 0 {
+		return nil, freshness, fmt.Errorf("系统指标不存在")
 	}
 
-	return &metrics, nil
+	return &SystemMetrics{
+		CPUUsage:    samples["cpu_usage"].Value,
+		MemoryUsage: samples["memory_usage"].Value,
+		Goroutines:  int(samples["goroutines"].Value),
+		HeapSize:    uint64(samples["heap_size"].Value),
+		GCCount:     uint32(samples["gc_count"].Value),
+		CPULimit:    samples["container_cpu_limit_cores"].Value,
+		MemoryLimit: uint64(samples["container_memory_limit_bytes"].Value),
+		Timestamp:   updatedAt,
+	}, freshness, nil
+}
+
+// latestSystemSamples 获取系统指标的最近采样和数据来源
+func (s *OptimizedMonitoringService) latestSystemSamples() (map[string]Models.MetricSample, string, error) {
+	recent := s.MonitoringCore().Recent()
+	samples := make(map[string]Models.MetricSample, len(systemMetricNames))
+	for _, name := range systemMetricNames {
+		if sample, exists := recent.Latest(name); exists {
+			samples[name] = sample
+		}
+	}
+	if len(samples) > 0 {
+		return samples, MetricSourceMemory, nil
+	}
+
+	s.bufferMutex.Lock()
+	history := s.metricHistory
+	s.bufferMutex.Unlock()
+	if history == nil {
+		return samples, MetricSourceNone, nil
+	}
+	samples, err := history.Latest(systemMetricNames)
+	if err != nil {
+		return nil, MetricSourceNone, err
+	}
+	if len(samples) == 0 {
+		return samples, MetricSourceNone, nil
+	}
+	return samples, MetricSourceDatabase, nil
+}
+
+// SetStaleAfter 设置指标过期时间，不大于0时使用默认值
+func (s *OptimizedMonitoringService) SetStaleAfter(staleAfter time.Duration) {
+	if staleAfter <= 0 {
+		staleAfter = defaultMetricStaleAfter
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.staleAfter = staleAfter
+}
+
+// StaleAfter 返回指标过期时间
+func (s *OptimizedMonitoringService) StaleAfter() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.staleAfter
 }
 
 // GetAppMetrics 获取应用指标
@@ -575,6 +718,7 @@ func (s *OptimizedMonitoringService) UpdateConfig(config *MonitoringConfig) {
 	s.checkInterval = config.CheckInterval
 	s.batchSize = config.BatchSize
 	s.flushInterval = config.FlushInterval
+	s.registerWithCore()
 }
 
 // GetConfig 获取配置
@@ -594,19 +738,27 @@ func (s *OptimizedMonitoringService) IsRunning() bool {
 }
 
 // GetCurrentMetrics 获取当前指标
+// freshness 中按指标类型返回数据来源（memory、database、none）、最近更新时间和是否过期
 func (s *OptimizedMonitoringService) GetCurrentMetrics() (map[string]interface{}, error) {
 	metrics := make(map[string]interface{})
+	freshness := make(map[string]MetricFreshness)
 
 	// 获取系统指标
-	if systemMetrics, err := s.GetSystemMetrics(); err == nil {
+	systemMetrics, systemFreshness, err := s.currentSystemMetrics()
+	if err == nil {
 		metrics["system"] = systemMetrics
 	}
+	freshness["system"] = systemFreshness
 
-	// 获取应用指标
+	// 获取应用指标，应用指标只缓存在内存中
+	var appUpdatedAt time.Time
 	if appMetrics, err := s.GetAppMetrics(); err == nil {
 		metrics["application"] = appMetrics
+		appUpdatedAt = appMetrics.Timestamp
 	}
+	freshness["application"] = newMetricFreshness(MetricSourceMemory, appUpdatedAt, time.Now(), s.StaleAfter())
 
+	metrics["freshness"] = freshness
 	return metrics, nil
 }
 
@@ -622,21 +774,30 @@ func (s *OptimizedMonitoringService) GetMetricsByTimeRange(startTime, endTime ti
 
 // GetActiveAlerts 获取活跃告警
 func (s *OptimizedMonitoringService) GetActiveAlerts() ([]interface{}, error) {
-	// 这里应该实现从数据库获取活跃告警的逻辑
-	// 暂时返回空切片
-	return []interface{}{}, nil
+	return alertsToInterfaces(s.MonitoringCore().Alerts("active", 0), ""), nil
 }
 
 // GetAlertHistory 获取告警历史
 func (s *OptimizedMonitoringService) GetAlertHistory(limit int) ([]interface{}, error) {
-	// 这里应该实现从数据库获取告警历史的逻辑
-	// 暂时返回空切片
-	return []interface{}{}, nil
+	return alertsToInterfaces(s.MonitoringCore().Alerts("", limit), ""), nil
+}
+
+// Subscribe 订阅实时指标和告警状态变化，ctx 取消后通道关闭
+func (s *OptimizedMonitoringService) Subscribe(ctx context.Context, filter MonitoringStreamFilter) <-chan MonitoringStreamEvent {
+	return s.MonitoringCore().Stream().Subscribe(ctx, filter)
+}
+
+// LatestMetrics 返回监控核心最近一次采集的指标值
+func (s *OptimizedMonitoringService) LatestMetrics() map[string]float64 {
+	return s.MonitoringCore().Values()
 }
 
 // CreateAlertRule 创建告警规则
+// 告警引擎规则（*AlertRule）添加到监控核心，其他类型暂不处理
 func (s *OptimizedMonitoringService) CreateAlertRule(rule interface{}) error {
-	// 这里应该实现创建告警规则的逻辑
+	if alertRule, ok := rule.(*AlertRule); ok {
+		return s.MonitoringCore().AddRule(alertRule)
+	}
 	return nil
 }
 
@@ -673,13 +834,16 @@ func (s *OptimizedMonitoringService) GetDB() interface{} {
 
 // GetMonitoringStats 获取监控统计信息
 func (s *OptimizedMonitoringService) GetMonitoringStats() (interface{}, error) {
-	// 这里应该实现获取监控统计信息的逻辑
-	// 暂时返回空结构
-	return map[string]interface{}{}, nil
+	return s.MonitoringCore().Stats(), nil
 }
 
 // RecordCustomMetric 记录自定义指标
+// 设置了指标基数保护时，标签不合法返回 ErrInvalidMetricLabels，序列数超过上限时按配置拒绝或合并为 overflow 序列
 func (s *OptimizedMonitoringService) RecordCustomMetric(metricType, name string, value float64, tags map[string]string) error {
+	tags, err := s.CardinalityGuard().Check(name, tags)
+	if err != nil {
+		return err
+	}
 	// 使用AddMetric方法记录自定义指标
 	s.AddMetric(name, value, tags)
 	return nil
@@ -694,17 +858,80 @@ func (s *OptimizedMonitoringService) GetSystemHealth() map[string]interface{} {
 		"alerts":    make(map[string]interface{}),
 	}
 
-	// 获取系统指标
-	if systemMetrics, err := s.GetSystemMetrics(); err == nil {
+	// 获取系统指标，优先从最近采样缓冲区读取
+	systemMetrics, freshness, err := s.currentSystemMetrics()
+	if err == nil {
 		health["metrics"].(map[string]interface{})["system"] = systemMetrics
 	}
+	health["freshness"] = freshness
 
 	return health
 }
 
 // GetAlerts 获取告警记录
+// severity 对应告警级别，为空时不过滤
 func (s *OptimizedMonitoringService) GetAlerts(status, severity string, limit int) ([]interface{}, error) {
-	// 这里应该实现从数据库获取告警记录的逻辑
-	// 暂时返回空切片
-	return []interface{}{}, nil
+	result := alertsToInterfaces(s.MonitoringCore().Alerts(status, 0), severity)
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// ListAlerts 分页获取告警记录，按创建时间和告警ID降序排列
+// 告警保存在内存中，按筛选条件过滤后再按游标或页码截取当前页；q 为空时不做额外筛选，visible 为空时不按用户过滤
+func (s *OptimizedMonitoringService) ListAlerts(status, severity string, q *Utils.ListQuery, req Utils.PageRequest, visible func(*Alert) bool) ([]interface{}, Utils.PageMeta, error) {
+	alerts := filterAlerts(s.MonitoringCore().Alerts(status, 0), visible)
+	sort.SliceStable(alerts, func(i, j int) bool {
+		if !alerts[i].CreatedAt.Equal(alerts[j].CreatedAt) {
+			return alerts[i].CreatedAt.After(alerts[j].CreatedAt)
+		}
+		return alerts[i].ID > alerts[j].ID
+	})
+	result := alertsToInterfaces(alerts, severity)
+	if q != nil && len(q.Filters) > 0 {
+		indexes, err := q.FilterRecords(result)
+		if err != nil {
+			return nil, Utils.PageMeta{}, err
+		}
+		filtered := make([]interface{}, 0, len(indexes))
+		for _, i := range indexes {
+			filtered = append(filtered, result[i])
+		}
+		result = filtered
+	}
+
+	start, end, meta, err := Utils.PageSlice(len(result), req, func(i int) (time.Time, string) {
+		alert := result[i].(*Alert)
+		return alert.CreatedAt, alert.ID
+	})
+	if err != nil {
+		return nil, meta, err
+	}
+	return result[start:end], meta, nil
+}
+
+// filterAlerts 保留 visible 返回 true 的告警，visible 为空时原样返回
+func filterAlerts(alerts []*Alert, visible func(*Alert) bool) []*Alert {
+	if visible == nil {
+		return alerts
+	}
+	filtered := make([]*Alert, 0, len(alerts))
+	for _, alert := range alerts {
+		if visible(alert) {
+			filtered = append(filtered, alert)
+		}
+	}
+	return filtered
+}
+
+// alertsToInterfaces 按级别过滤告警并转换为通用切片
+func alertsToInterfaces(alerts []*Alert, level string) []interface{} {
+	result := make([]interface{}, 0, len(alerts))
+	for _, alert := range alerts {
+		if level == "" || string(alert.Level) == level {
+			result = append(result, alert)
+		}
+	}
+	return result
 }
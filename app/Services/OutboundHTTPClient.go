@@ -0,0 +1,549 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Utils"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 熔断器状态
+const (
+	BreakerStateClosed   = "closed"
+	BreakerStateOpen     = "open"
+	BreakerStateHalfOpen = "half_open"
+)
+
+// 外部依赖告警类型
+const (
+	OutboundAlertLatencySLO   = "outbound_latency_slo"
+	OutboundAlertErrorRateSLO = "outbound_error_rate_slo"
+	OutboundAlertCircuitOpen  = "outbound_circuit_open"
+)
+
+// ErrCircuitOpen 依赖处于熔断状态，请求未发出
+var ErrCircuitOpen = errors.New("依赖熔断中，请求被拒绝")
+
+// OutboundPolicy 单个依赖的调用策略
+type OutboundPolicy struct {
+	Timeout      time.Duration `json:"timeout"`
+	MaxRetries   int           `json:"max_retries"`
+	RetryBackoff time.Duration `json:"retry_backoff"`
+}
+
+// OutboundDependencyStats 外部依赖调用统计
+//
+// Requests、Failures、ErrorRate 和延迟为统计窗口内的值，Total 开头的字段为累计值。
+type OutboundDependencyStats struct {
+	Name          string  `json:"name"`
+	State         string  `json:"state"`
+	Requests      int     `json:"requests"`
+	Failures      int     `json:"failures"`
+	ErrorRate     float64 `json:"error_rate"`
+	AvgLatency    float64 `json:"avg_latency"` // 毫秒
+	P95Latency    float64 `json:"p95_latency"` // 毫秒
+	Retries       int     `json:"retries"`
+	TotalRequests int64   `json:"total_requests"`
+	TotalFailures int64   `json:"total_failures"`
+	TotalRejected int64   `json:"total_rejected"`
+	BudgetDenied  int64   `json:"budget_denied"` // 因重试预算耗尽未重试的次数
+	LastError     string  `json:"last_error,omitempty"`
+}
+
+// outboundSample 单次请求的结果
+type outboundSample struct {
+	at       time.Time
+	duration time.Duration
+	failed   bool
+}
+
+// outboundDependency 单个依赖的统计和熔断状态
+type outboundDependency struct {
+	name   string
+	policy OutboundPolicy
+
+	samples []outboundSample
+	retries []time.Time
+
+	state         string
+	openedAt      time.Time
+	probes        int // 半开状态下已放行的探测请求数
+	probeSuccess  int
+	totalRequests int64
+	totalFailures int64
+	totalRejected int64
+	budgetDenied  int64
+	lastError     string
+	lastAlerts    map[string]time.Time
+}
+
+// OutboundHTTPClient 带监控的出站HTTP客户端
+// 功能说明：
+// 1. 按依赖名称统计请求数、错误率和延迟（统计窗口内的P95），网络错误、5xx和429视为失败
+// 2. 窗口内错误率达到 BreakerFailureRate 时熔断，熔断期间直接返回 ErrCircuitOpen
+// 3. 熔断 BreakerOpenTimeout 后进入半开状态，放行少量探测请求，全部成功则恢复，失败则重新熔断
+// 4. 失败时按指数退避重试，窗口内重试次数受重试预算限制，避免依赖故障时放大流量
+// 5. P95延迟或错误率超过SLO、依赖熔断时发出告警，同一依赖同类告警有冷却时间
+//
+// 只有请求体可以重放（无请求体或设置了 GetBody）的请求才会重试。
+type OutboundHTTPClient struct {
+	config *Config.MonitoringConfig
+	client *http.Client
+
+	*outboundState
+}
+
+// outboundState 依赖统计、调用策略和熔断状态，WithHTTPClient 创建的客户端共享同一份状态
+type outboundState struct {
+	mu           sync.Mutex
+	dependencies map[string]*outboundDependency
+	policies     map[string]OutboundPolicy
+	alertHandler func(MonitoringAlert)
+}
+
+var (
+	outboundClient     *OutboundHTTPClient
+	outboundClientOnce sync.Once
+)
+
+// GetOutboundHTTPClient 获取全局出站HTTP客户端，所有依赖共享统计和熔断状态
+func GetOutboundHTTPClient() *OutboundHTTPClient {
+	outboundClientOnce.Do(func() {
+		outboundClient = NewOutboundHTTPClient(nil)
+	})
+	return outboundClient
+}
+
+// outboundClientFor 获取全局出站HTTP客户端并设置依赖的超时，失败重试由调用方负责
+func outboundClientFor(dependency string, timeout time.Duration) *OutboundHTTPClient {
+	client := GetOutboundHTTPClient()
+	policy := client.DefaultPolicy()
+	policy.MaxRetries = 0
+	if timeout > 0 {
+		policy.Timeout = timeout
+	}
+	client.SetPolicy(dependency, policy)
+	return client
+}
+
+// NewOutboundHTTPClient 创建出站HTTP客户端
+//
+// config 为 nil 时使用全局监控配置，全局配置未加载时使用默认值。
+func NewOutboundHTTPClient(config *Config.MonitoringConfig) *OutboundHTTPClient {
+	if config == nil {
+		if global := Config.GetConfig(); global != nil {
+			config = &global.Monitoring
+		} else {
+			config = &Config.MonitoringConfig{}
+			config.SetDefaults()
+		}
+	}
+
+	return &OutboundHTTPClient{
+		config: config,
+		client: &http.Client{},
+		outboundState: &outboundState{
+			dependencies: make(map[string]*outboundDependency),
+			policies:     make(map[string]OutboundPolicy),
+		},
+	}
+}
+
+// WithHTTPClient 使用指定的 http.Client 发送请求，统计、调用策略和熔断状态与原客户端共享
+//
+// 用于需要自定义连接方式的依赖（如限制连接地址、不跟随重定向），这些依赖的调用同样出现在 Stats 中。
+func (c *OutboundHTTPClient) WithHTTPClient(client *http.Client) *OutboundHTTPClient {
+	return &OutboundHTTPClient{config: c.config, client: client, outboundState: c.outboundState}
+}
+
+// DefaultPolicy 按全局配置生成的调用策略
+func (c *OutboundHTTPClient) DefaultPolicy() OutboundPolicy {
+	return OutboundPolicy{
+		Timeout:      c.config.OutboundHTTP.Timeout,
+		MaxRetries:   c.config.OutboundHTTP.MaxRetries,
+		RetryBackoff: c.config.OutboundHTTP.RetryBackoff,
+	}
+}
+
+// SetPolicy 设置依赖的调用策略，覆盖全局配置
+func (c *OutboundHTTPClient) SetPolicy(dependency string, policy OutboundPolicy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.policies[dependency] = policy
+	if dep, ok := c.dependencies[dependency]; ok {
+		dep.policy = policy
+	}
+}
+
+// OnAlert 设置告警处理函数，未设置时只记录日志
+func (c *OutboundHTTPClient) OnAlert(handler func(MonitoringAlert)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.alertHandler = handler
+}
+
+// Do 以依赖名称发送请求
+//
+// 请求上下文中有请求ID和关联ID时设置 X-Request-ID 和 X-Correlation-ID 请求头。
+// 返回的响应与 http.Client.Do 相同，调用方负责关闭响应体；超时覆盖读取响应体的时间。
+// 重试全部失败时返回最后一次的响应或错误。
+func (c *OutboundHTTPClient) Do(dependency string, req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	policy := c.dependency(dependency).policy
+	c.mu.Unlock()
+
+	Utils.SetRequestIDHeaders(req.Header, Utils.RequestIDFromContext(req.Context()), Utils.CorrelationIDFromContext(req.Context()))
+
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	backoff := policy.RetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		if !c.allow(dependency) {
+			return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, dependency)
+		}
+
+		attemptReq := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		var ctx context.Context
+		var cancel context.CancelFunc
+		if policy.Timeout > 0 {
+			ctx, cancel = context.WithTimeout(req.Context(), policy.Timeout)
+		} else {
+			ctx, cancel = context.WithCancel(req.Context())
+		}
+		start := time.Now()
+		resp, err := c.client.Do(attemptReq.WithContext(ctx))
+		failed := err != nil || resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+		c.record(dependency, time.Since(start), failed, outboundError(resp, err))
+		if recorder := DebugRecorderFromContext(req.Context()); recorder != nil {
+			recorder.RecordHTTP(outboundDebugCall(dependency, attemptReq, attempt, resp, err, time.Since(start)), start)
+		}
+
+		if !failed || attempt >= policy.MaxRetries || !replayable || req.Context().Err() != nil || !c.takeRetry(dependency) {
+			if resp != nil {
+				resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+			} else {
+				cancel()
+			}
+			return resp, err
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		cancel()
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// Get 以依赖名称发送GET请求
+func (c *OutboundHTTPClient) Get(ctx context.Context, dependency, rawURL string, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	return c.Do(dependency, req)
+}
+
+// outboundHost 以URL的主机名作为依赖名称，解析失败时返回原始URL
+func outboundHost(rawURL string) string {
+	if parsed, err := url.Parse(rawURL); err == nil && parsed.Host != "" {
+		return parsed.Host
+	}
+	return rawURL
+}
+
+// outboundError 失败原因描述
+func outboundError(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		return resp.Status
+	}
+	return ""
+}
+
+// outboundDebugCall 转换为调试记录，URL不包含查询参数和用户信息
+func outboundDebugCall(dependency string, req *http.Request, attempt int, resp *http.Response, err error, duration time.Duration) DebugHTTPCall {
+	target := *req.URL
+	target.User = nil
+	target.RawQuery = ""
+	target.Fragment = ""
+	call := DebugHTTPCall{
+		Dependency: dependency,
+		Method:     req.Method,
+		URL:        target.String(),
+		Attempt:    attempt + 1,
+		DurationMs: debugMs(duration),
+		Error:      outboundError(resp, err),
+	}
+	if resp != nil {
+		call.StatusCode = resp.StatusCode
+	}
+	return call
+}
+
+// cancelOnClose 关闭响应体时释放请求的超时上下文
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// dependency 获取或创建依赖状态，调用方需持有锁
+func (c *OutboundHTTPClient) dependency(name string) *outboundDependency {
+	dep, ok := c.dependencies[name]
+	if !ok {
+		policy, exists := c.policies[name]
+		if !exists {
+			policy = c.DefaultPolicy()
+		}
+		dep = &outboundDependency{
+			name:       name,
+			policy:     policy,
+			state:      BreakerStateClosed,
+			lastAlerts: make(map[string]time.Time),
+		}
+		c.dependencies[name] = dep
+	}
+	return dep
+}
+
+// allow 按熔断状态判断是否放行请求
+func (c *OutboundHTTPClient) allow(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dep := c.dependency(name)
+	if dep.state == BreakerStateOpen && time.Since(dep.openedAt) >= c.config.OutboundHTTP.BreakerOpenTimeout {
+		dep.state = BreakerStateHalfOpen
+		dep.probes = 0
+		dep.probeSuccess = 0
+	}
+
+	switch dep.state {
+	case BreakerStateOpen:
+		dep.totalRejected++
+		return false
+	case BreakerStateHalfOpen:
+		if dep.probes >= c.halfOpenProbes() {
+			dep.totalRejected++
+			return false
+		}
+		dep.probes++
+	}
+	return true
+}
+
+// halfOpenProbes 半开状态允许的探测请求数
+func (c *OutboundHTTPClient) halfOpenProbes() int {
+	if c.config.OutboundHTTP.BreakerHalfOpenProbes <= 0 {
+		return 1
+	}
+	return c.config.OutboundHTTP.BreakerHalfOpenProbes
+}
+
+// takeRetry 检查重试预算，预算充足时占用一次重试
+// 窗口内允许的重试次数为请求数乘以 RetryBudgetRatio，且不少于单次请求的最大重试次数
+func (c *OutboundHTTPClient) takeRetry(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dep := c.dependency(name)
+	now := time.Now()
+	dep.prune(now, c.config.OutboundHTTP.Window)
+
+	budget := int(float64(len(dep.samples)) * c.config.OutboundHTTP.RetryBudgetRatio)
+	if budget < dep.policy.MaxRetries {
+		budget = dep.policy.MaxRetries
+	}
+	if len(dep.retries) >= budget {
+		dep.budgetDenied++
+		return false
+	}
+	dep.retries = append(dep.retries, now)
+	return true
+}
+
+// record 记录请求结果，更新熔断状态并检查SLO
+func (c *OutboundHTTPClient) record(name string, duration time.Duration, failed bool, errMessage string) {
+	c.mu.Lock()
+
+	cfg := c.config.OutboundHTTP
+	dep := c.dependency(name)
+	now := time.Now()
+	dep.samples = append(dep.samples, outboundSample{at: now, duration: duration, failed: failed})
+	dep.prune(now, cfg.Window)
+	dep.totalRequests++
+	if failed {
+		dep.totalFailures++
+		dep.lastError = errMessage
+	}
+
+	var alerts []MonitoringAlert
+	stats := dep.stats()
+	switch dep.state {
+	case BreakerStateHalfOpen:
+		if failed {
+			dep.open(now)
+			alerts = append(alerts, c.newAlert(dep, OutboundAlertCircuitOpen, "high",
+				fmt.Sprintf("外部依赖 %s 半开探测失败，重新熔断", name), stats, now))
+		} else if dep.probeSuccess++; dep.probeSuccess >= c.halfOpenProbes() {
+			// 探测全部成功，恢复并清空窗口，避免熔断前的失败再次触发熔断
+			dep.state = BreakerStateClosed
+			dep.samples = dep.samples[:0]
+			log.Printf("外部依赖 %s 熔断恢复", name)
+		}
+	case BreakerStateClosed:
+		if cfg.BreakerFailureRate > 0 && stats.Requests >= cfg.MinRequests && stats.ErrorRate >= cfg.BreakerFailureRate {
+			dep.open(now)
+			alerts = append(alerts, c.newAlert(dep, OutboundAlertCircuitOpen, "high",
+				fmt.Sprintf("外部依赖 %s 错误率 %.2f%% 达到熔断阈值，已熔断", name, stats.ErrorRate), stats, now))
+		}
+	}
+
+	if stats.Requests >= cfg.MinRequests {
+		if cfg.LatencySLO > 0 && stats.P95Latency > float64(cfg.LatencySLO)/float64(time.Millisecond) {
+			alerts = append(alerts, c.newAlert(dep, OutboundAlertLatencySLO, "medium",
+				fmt.Sprintf("外部依赖 %s P95延迟 %.0fms 超过SLO %s", name, stats.P95Latency, cfg.LatencySLO), stats, now))
+		}
+		if cfg.ErrorRateSLO > 0 && stats.ErrorRate > cfg.ErrorRateSLO {
+			alerts = append(alerts, c.newAlert(dep, OutboundAlertErrorRateSLO, "high",
+				fmt.Sprintf("外部依赖 %s 错误率 %.2f%% 超过SLO %.2f%%", name, stats.ErrorRate, cfg.ErrorRateSLO), stats, now))
+		}
+	}
+
+	var triggered []MonitoringAlert
+	for _, alert := range alerts {
+		if last, ok := dep.lastAlerts[alert.Type]; ok && now.Sub(last) < cfg.AlertCooldown {
+			continue
+		}
+		dep.lastAlerts[alert.Type] = now
+		triggered = append(triggered, alert)
+	}
+	handler := c.alertHandler
+	c.mu.Unlock()
+
+	for _, alert := range triggered {
+		log.Printf("外部依赖告警: %s - %s", alert.Type, alert.Message)
+		if handler != nil {
+			handler(alert)
+		}
+	}
+}
+
+// newAlert 创建外部依赖告警
+func (c *OutboundHTTPClient) newAlert(dep *outboundDependency, alertType, severity, message string, stats OutboundDependencyStats, now time.Time) MonitoringAlert {
+	return MonitoringAlert{
+		ID:        fmt.Sprintf("%s_%s_%d", alertType, dep.name, now.UnixNano()),
+		Type:      alertType,
+		Severity:  severity,
+		Title:     fmt.Sprintf("外部依赖告警: %s", dep.name),
+		Message:   message,
+		Source:    "outbound_http_client",
+		Timestamp: now,
+		Metadata: map[string]interface{}{
+			"dependency":  dep.name,
+			"state":       dep.state,
+			"requests":    stats.Requests,
+			"error_rate":  stats.ErrorRate,
+			"p95_latency": stats.P95Latency,
+			"last_error":  dep.lastError,
+		},
+	}
+}
+
+// open 进入熔断状态
+func (d *outboundDependency) open(now time.Time) {
+	d.state = BreakerStateOpen
+	d.openedAt = now
+}
+
+// prune 删除统计窗口之外的请求和重试记录
+func (d *outboundDependency) prune(now time.Time, window time.Duration) {
+	cutoff := now.Add(-window)
+	i := sort.Search(len(d.samples), func(i int) bool { return d.samples[i].at.After(cutoff) })
+	d.samples = append(d.samples[:0], d.samples[i:]...)
+	j := sort.Search(len(d.retries), func(j int) bool { return d.retries[j].After(cutoff) })
+	d.retries = append(d.retries[:0], d.retries[j:]...)
+}
+
+// stats 计算统计窗口内的指标
+func (d *outboundDependency) stats() OutboundDependencyStats {
+	stats := OutboundDependencyStats{
+		Name:          d.name,
+		State:         d.state,
+		Requests:      len(d.samples),
+		Retries:       len(d.retries),
+		TotalRequests: d.totalRequests,
+		TotalFailures: d.totalFailures,
+		TotalRejected: d.totalRejected,
+		BudgetDenied:  d.budgetDenied,
+		LastError:     d.lastError,
+	}
+	if stats.Requests == 0 {
+		return stats
+	}
+
+	durations := make([]float64, len(d.samples))
+	var total float64
+	for i, sample := range d.samples {
+		if sample.failed {
+			stats.Failures++
+		}
+		durations[i] = float64(sample.duration) / float64(time.Millisecond)
+		total += durations[i]
+	}
+	stats.ErrorRate = float64(stats.Failures) / float64(stats.Requests) * 100
+	stats.AvgLatency = total / float64(stats.Requests)
+	stats.P95Latency = percentile(durations, 0.95)
+	return stats
+}
+
+// Stats 所有依赖的调用统计，按名称排序
+func (c *OutboundHTTPClient) Stats() []OutboundDependencyStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	result := make([]OutboundDependencyStats, 0, len(c.dependencies))
+	for _, dep := range c.dependencies {
+		dep.prune(now, c.config.OutboundHTTP.Window)
+		result = append(result, dep.stats())
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
@@ -0,0 +1,214 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PasswordExpiryStatus 用户密码过期状态
+type PasswordExpiryStatus struct {
+	ChangedAt  time.Time `json:"changed_at"`           // 最后修改密码时间
+	ExpiresAt  time.Time `json:"expires_at"`           // 密码过期时间
+	Expired    bool      `json:"expired"`              // 是否已过期
+	MustChange bool      `json:"must_change_password"` // 是否必须修改密码
+}
+
+// PasswordExpiryService 密码过期策略服务
+// 功能说明：
+// 1. 启用强制修改密码时，按最后修改密码时间（未修改过按注册时间）计算密码是否超过更改间隔
+// 2. 密码过期的用户被标记为必须修改密码，由中间件限制其只能访问修改密码接口
+// 3. 在密码过期前的提醒窗口内向用户发送一次提醒，按用户的通知偏好通过邮件、站内通知或短信发送，修改密码后重新计算
+type PasswordExpiryService struct {
+	db     *gorm.DB
+	config *Config.BaseSecurityConfig
+	mailer NotificationMailer
+}
+
+// NewPasswordExpiryService 创建密码过期策略服务
+//
+// config 为 nil 时使用全局配置；mailer 为 nil 且全局邮件服务已配置时使用 EmailService。
+func NewPasswordExpiryService(db *gorm.DB, config *Config.BaseSecurityConfig, mailer NotificationMailer) *PasswordExpiryService {
+	if config == nil {
+		if globalConfig := Config.GetConfig(); globalConfig != nil {
+			config = &globalConfig.Security.BaseSecurity
+		} else {
+			securityConfig := &Config.SecurityConfig{}
+			securityConfig.SetDefaults()
+			config = &securityConfig.BaseSecurity
+		}
+	}
+	if mailer == nil {
+		mailer = defaultNotificationMailer()
+	}
+
+	return &PasswordExpiryService{
+		db:     db,
+		config: config,
+		mailer: mailer,
+	}
+}
+
+// Enabled 是否启用密码过期策略
+func (s *PasswordExpiryService) Enabled() bool {
+	return s.config.ForcePasswordChange && s.config.PasswordChangeInterval > 0
+}
+
+// Status 计算用户的密码过期状态
+func (s *PasswordExpiryService) Status(user *Models.User) PasswordExpiryStatus {
+	status := PasswordExpiryStatus{
+		ChangedAt:  user.CreatedAt,
+		MustChange: user.MustChangePassword,
+	}
+	if user.PasswordChangedAt != nil {
+		status.ChangedAt = *user.PasswordChangedAt
+	}
+	if s.Enabled() {
+		status.ExpiresAt = status.ChangedAt.Add(s.config.PasswordChangeInterval)
+		status.Expired = !time.Now().Before(status.ExpiresAt)
+		status.MustChange = status.MustChange || status.Expired
+	}
+	return status
+}
+
+// Enforce 检查用户是否必须修改密码，密码已过期但尚未标记时写入标记
+// 每个认证请求都会调用，启用模型缓存时先读缓存，写入标记后缓存随之失效
+func (s *PasswordExpiryService) Enforce(userID uint) (bool, error) {
+	user, err := FindUserCached(s.db, userID)
+	if err != nil {
+		return false, err
+	}
+
+	status := s.Status(&user)
+	if status.MustChange && !user.MustChangePassword {
+		if err := s.db.Model(&Models.User{}).Where("id = ?", userID).
+			Update("must_change_password", true).Error; err != nil {
+			return true, err
+		}
+	}
+	return status.MustChange, nil
+}
+
+// RequirePasswordChange 要求用户下次访问时修改密码（如管理员重置密码后）
+func (s *PasswordExpiryService) RequirePasswordChange(userID uint) error {
+	return s.db.Model(&Models.User{}).Where("id = ?", userID).
+		Update("must_change_password", true).Error
+}
+
+// NotifyExpiringPasswords 向提醒窗口内即将过期的用户发送提醒，返回提醒的用户数量
+//
+// 每个密码周期只提醒一次：最近提醒时间早于最后修改密码时间时才会再次发送。
+// 用户关闭了全部可用的通知渠道时不记录提醒时间，重新开启后仍会收到本周期的提醒。
+func (s *PasswordExpiryService) NotifyExpiringPasswords() (int, error) {
+	notifications, sms := DefaultNotificationService(), DefaultSMSService()
+	if !s.Enabled() || s.config.PasswordExpiryWarning <= 0 || (s.mailer == nil && notifications == nil && sms == nil) {
+		return 0, nil
+	}
+
+	now := time.Now()
+	warnBefore := now.Add(-(s.config.PasswordChangeInterval - s.config.PasswordExpiryWarning))
+	expiredBefore := now.Add(-s.config.PasswordChangeInterval)
+	changedAt := "COALESCE(password_changed_at, created_at)"
+
+	query := s.db.Select("id", "username", "email", "phone", "created_at", "password_changed_at").
+		Where("status = ? AND must_change_password = ?", 1, false).
+		Where(changedAt+" <= ? AND "+changedAt+" > ?", warnBefore, expiredBefore).
+		Where("password_expiry_notified_at IS NULL OR password_expiry_notified_at < " + changedAt)
+	if notifications == nil && sms == nil {
+		// 只能发邮件时跳过没有邮箱的用户
+		query = query.Where("email <> ''")
+	}
+
+	var users []Models.User
+	err := query.Find(&users).Error
+	if err != nil {
+		return 0, err
+	}
+
+	dispatcher := NewUserNotificationDispatcher(s.db, s.mailer)
+	dispatcher.SetNotificationService(notifications)
+	dispatcher.SetSMSService(sms)
+
+	sent := 0
+	for i := range users {
+		user := &users[i]
+		expiresAt := s.Status(user).ExpiresAt
+		formatted := expiresAt.Format("2006-01-02 15:04:05")
+		result := dispatcher.Dispatch(context.Background(), user, UserNotification{
+			Type:         Models.NotificationTypePasswordExpiring,
+			Title:        "密码即将过期",
+			Content:      fmt.Sprintf("您的账户密码将于 %s 过期，请尽快修改密码。", formatted),
+			Data:         map[string]interface{}{"expires_at": expiresAt},
+			EmailSubject: "密码即将过期提醒",
+			EmailBody:    passwordExpiryEmailBody(user.Username, expiresAt),
+			SMSTemplate:  SMSTemplatePasswordExpiring,
+			SMSVars:      map[string]string{"expires_at": formatted},
+		})
+		for channel, err := range result.Failures {
+			log.Printf("发送密码过期提醒失败: user=%d, channel=%s, error=%v", user.ID, channel, err)
+		}
+		if len(result.Delivered) == 0 {
+			continue
+		}
+		if err := s.db.Model(&Models.User{}).Where("id = ?", user.ID).
+			Update("password_expiry_notified_at", now).Error; err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// StartNotifier 按固定间隔发送密码过期提醒，ctx 取消后停止
+func (s *PasswordExpiryService) StartNotifier(ctx context.Context, interval time.Duration) {
+	if !s.Enabled() || interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if sent, err := s.NotifyExpiringPasswords(); err != nil {
+				log.Printf("检查即将过期的密码失败: %v", err)
+			} else if sent > 0 {
+				log.Printf("已发送 %d 封密码过期提醒邮件", sent)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// passwordChangedUpdates 修改密码时需要同时更新的字段，重置过期标记和提醒记录
+func passwordChangedUpdates(hashedPassword string) map[string]interface{} {
+	return map[string]interface{}{
+		"password":                    hashedPassword,
+		"password_changed_at":         time.Now(),
+		"must_change_password":        false,
+		"password_expiry_notified_at": nil,
+	}
+}
+
+// passwordExpiryEmailBody 生成密码过期提醒邮件内容
+func passwordExpiryEmailBody(username string, expiresAt time.Time) string {
+	days := int(math.Ceil(time.Until(expiresAt).Hours() / 24))
+	return fmt.Sprintf(`
+		<h2>密码即将过期</h2>
+		<p>您好 %s，</p>
+		<p>您的账户密码将在 %d 天后（%s）过期。</p>
+		<p>过期后登录时需要先修改密码才能继续使用，建议您尽快登录并修改密码。</p>
+	`, html.EscapeString(username), days, expiresAt.Format("2006-01-02 15:04:05"))
+}
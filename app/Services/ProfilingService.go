@@ -0,0 +1,432 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProfileTypes 支持采集的剖析类型
+var ProfileTypes = []string{"goroutine", "heap", "allocs", "block", "mutex", "threadcreate", "cpu"}
+
+// 自动采集的触发原因
+const (
+	ProfileReasonManual             = "manual"
+	ProfileReasonGoroutineThreshold = "goroutine_threshold"
+	ProfileReasonHeapThreshold      = "heap_threshold"
+	ProfileReasonMemoryLeak         = "memory_leak"
+)
+
+// profileLeakChecks 堆内存连续增长多少次判定为疑似泄漏
+const profileLeakChecks = 3
+
+// profileNamePattern 剖析文件名格式：<类型>-<时间>-<原因>.pprof
+var profileNamePattern = regexp.MustCompile(`^(` + strings.Join(ProfileTypes, "|") + `)-(\d{8}-\d{6}\.\d{3})-([a-z0-9_]+)\.pprof$`)
+
+const profileTimeLayout = "20060102-150405.000"
+
+var (
+	// ErrProfileNotFound 剖析文件不存在或文件名非法
+	ErrProfileNotFound = errors.New("剖析文件不存在")
+	// ErrProfileTypeUnsupported 不支持的剖析类型
+	ErrProfileTypeUnsupported = errors.New("不支持的剖析类型")
+	// ErrCPUProfileBusy 已有CPU剖析正在进行
+	ErrCPUProfileBusy = errors.New("已有CPU剖析正在进行")
+)
+
+// ProfileInfo 剖析文件信息
+type ProfileInfo struct {
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Reason    string    `json:"reason"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ProfilingService 性能剖析服务
+// 功能说明：
+// 1. 按 ApplicationMonitoring 的协程数和堆内存阈值定期检查，突破时自动采集 goroutine/heap 剖析文件
+// 2. 堆内存连续多次增长超过 MemoryLeakThreshold(%) 时判定为疑似泄漏并采集 heap 剖析文件
+// 3. 同一原因的自动采集有冷却时间，避免持续超阈值时反复采集
+// 4. 剖析文件保存在磁盘上，只保留最新的 MaxProfiles 个
+// 5. 支持手动采集、列出、下载和删除剖析文件
+//
+// 剖析文件为 pprof 格式，可直接使用 go tool pprof 分析。
+type ProfilingService struct {
+	config *Config.MonitoringConfig
+
+	mu          sync.Mutex
+	lastCapture map[string]time.Time
+	lastHeap    uint64
+	heapGrowth  int
+
+	cpuMu sync.Mutex
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	isRunning bool
+}
+
+// NewProfilingService 创建性能剖析服务
+//
+// config 为 nil 时使用全局监控配置，全局配置未加载时使用默认值。
+func NewProfilingService(config *Config.MonitoringConfig) *ProfilingService {
+	if config == nil {
+		if global := Config.GetConfig(); global != nil {
+			config = &global.Monitoring
+		} else {
+			config = &Config.MonitoringConfig{}
+			config.SetDefaults()
+		}
+	}
+
+	return &ProfilingService{
+		config:      config,
+		lastCapture: make(map[string]time.Time),
+	}
+}
+
+// GetConfig 获取监控配置
+func (s *ProfilingService) GetConfig() *Config.MonitoringConfig {
+	return s.config
+}
+
+// Start 启动阈值检查
+//
+// 未开启自动采集时不启动后台协程，手动采集和下载不受影响。
+func (s *ProfilingService) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isRunning {
+		return fmt.Errorf("profiling service is already running")
+	}
+	if !s.config.Profiling.AutoCapture || s.config.Profiling.CheckInterval <= 0 {
+		return nil
+	}
+	if err := os.MkdirAll(s.config.Profiling.Path, 0755); err != nil {
+		return fmt.Errorf("创建剖析文件目录失败: %v", err)
+	}
+
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	go s.checkLoop(s.ctx)
+
+	s.isRunning = true
+	return nil
+}
+
+// Stop 停止阈值检查
+func (s *ProfilingService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isRunning {
+		return
+	}
+	s.cancel()
+	s.isRunning = false
+}
+
+// checkLoop 阈值检查循环
+func (s *ProfilingService) checkLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.config.Profiling.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.CheckThresholds()
+		}
+	}
+}
+
+// CheckThresholds 检查阈值，突破时自动采集剖析文件
+//
+// 返回本次采集的剖析文件。
+func (s *ProfilingService) CheckThresholds() []ProfileInfo {
+	app := s.config.ApplicationMonitoring
+
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	var triggers []struct{ profileType, reason string }
+	if app.GoroutineThreshold > 0 && runtime.NumGoroutine() >= app.GoroutineThreshold {
+		triggers = append(triggers, struct{ profileType, reason string }{"goroutine", ProfileReasonGoroutineThreshold})
+	}
+	if app.HeapThreshold > 0 && memStats.HeapAlloc >= uint64(app.HeapThreshold)*1024*1024 {
+		triggers = append(triggers, struct{ profileType, reason string }{"heap", ProfileReasonHeapThreshold})
+	}
+	if s.heapLeaking(memStats.HeapAlloc, app.MemoryLeakThreshold) {
+		triggers = append(triggers, struct{ profileType, reason string }{"heap", ProfileReasonMemoryLeak})
+	}
+
+	var captured []ProfileInfo
+	for _, trigger := range triggers {
+		if !s.acquireCooldown(trigger.reason) {
+			continue
+		}
+		info, err := s.Capture(trigger.profileType, trigger.reason)
+		if err != nil {
+			log.Printf("自动采集%s剖析文件失败（%s）: %v", trigger.profileType, trigger.reason, err)
+			continue
+		}
+		log.Printf("阈值突破（%s），已采集剖析文件: %s", trigger.reason, info.Name)
+		captured = append(captured, *info)
+	}
+	return captured
+}
+
+// heapLeaking 堆内存连续 profileLeakChecks 次增长超过 threshold(%) 时判定为疑似泄漏
+func (s *ProfilingService) heapLeaking(heap uint64, threshold float64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	last := s.lastHeap
+	s.lastHeap = heap
+	if threshold <= 0 || last == 0 {
+		return false
+	}
+
+	if float64(heap) >= float64(last)*(1+threshold/100) {
+		s.heapGrowth++
+	} else {
+		s.heapGrowth = 0
+	}
+	if s.heapGrowth < profileLeakChecks {
+		return false
+	}
+	s.heapGrowth = 0
+	return true
+}
+
+// acquireCooldown 检查并占用某个原因的冷却时间
+func (s *ProfilingService) acquireCooldown(reason string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if last, ok := s.lastCapture[reason]; ok && now.Sub(last) < s.config.Profiling.CaptureCooldown {
+		return false
+	}
+	s.lastCapture[reason] = now
+	return true
+}
+
+// Capture 采集剖析文件（CPU剖析请使用 CaptureCPU）
+func (s *ProfilingService) Capture(profileType, reason string) (*ProfileInfo, error) {
+	if profileType == "cpu" {
+		return nil, ErrProfileTypeUnsupported
+	}
+	profile := pprof.Lookup(profileType)
+	if profile == nil {
+		return nil, ErrProfileTypeUnsupported
+	}
+
+	return s.writeProfile(profileType, reason, func(file *os.File) error {
+		return profile.WriteTo(file, 0)
+	})
+}
+
+// CaptureCPU 采集CPU剖析文件
+//
+// 采集期间阻塞，duration 超过 MaxCPUDuration 时按 MaxCPUDuration 采集；
+// 同一时间只能进行一个CPU剖析（包括 /debug/pprof/profile）。
+func (s *ProfilingService) CaptureCPU(ctx context.Context, duration time.Duration, reason string) (*ProfileInfo, error) {
+	if limit := s.config.Profiling.MaxCPUDuration; limit > 0 && duration > limit {
+		duration = limit
+	}
+	if duration <= 0 {
+		duration = 10 * time.Second
+	}
+	if !s.cpuMu.TryLock() {
+		return nil, ErrCPUProfileBusy
+	}
+	defer s.cpuMu.Unlock()
+
+	return s.writeProfile("cpu", reason, func(file *os.File) error {
+		if err := pprof.StartCPUProfile(file); err != nil {
+			return ErrCPUProfileBusy
+		}
+		timer := time.NewTimer(duration)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+		pprof.StopCPUProfile()
+		return ctx.Err()
+	})
+}
+
+// writeProfile 写入剖析文件并执行保留策略
+func (s *ProfilingService) writeProfile(profileType, reason string, write func(*os.File) error) (*ProfileInfo, error) {
+	reason = sanitizeProfileReason(reason)
+	if err := os.MkdirAll(s.config.Profiling.Path, 0755); err != nil {
+		return nil, fmt.Errorf("创建剖析文件目录失败: %v", err)
+	}
+
+	// 同一毫秒内的重名文件顺延1毫秒
+	createdAt := time.Now()
+	var file *os.File
+	var name string
+	for i := 0; i < 10; i++ {
+		name = fmt.Sprintf("%s-%s-%s.pprof", profileType, createdAt.Format(profileTimeLayout), reason)
+		var err error
+		file, err = os.OpenFile(filepath.Join(s.config.Profiling.Path, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("创建剖析文件失败: %v", err)
+		}
+		createdAt = createdAt.Add(time.Millisecond)
+	}
+	if file == nil {
+		return nil, fmt.Errorf("创建剖析文件失败: 文件名冲突")
+	}
+
+	err := write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	path := filepath.Join(s.config.Profiling.Path, name)
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	s.enforceRetention()
+
+	return &ProfileInfo{
+		Name:      name,
+		Type:      profileType,
+		Reason:    reason,
+		Size:      stat.Size(),
+		CreatedAt: createdAt.Truncate(time.Millisecond),
+	}, nil
+}
+
+// enforceRetention 只保留最新的 MaxProfiles 个剖析文件
+func (s *ProfilingService) enforceRetention() {
+	limit := s.config.Profiling.MaxProfiles
+	if limit <= 0 {
+		return
+	}
+	profiles, err := s.List()
+	if err != nil || len(profiles) <= limit {
+		return
+	}
+	for _, profile := range profiles[limit:] {
+		os.Remove(filepath.Join(s.config.Profiling.Path, profile.Name))
+	}
+}
+
+// List 列出剖析文件，按时间倒序
+func (s *ProfilingService) List() ([]ProfileInfo, error) {
+	entries, err := os.ReadDir(s.config.Profiling.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []ProfileInfo{}, nil
+		}
+		return nil, err
+	}
+
+	profiles := make([]ProfileInfo, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, ok := parseProfileName(entry.Name())
+		if !ok {
+			continue
+		}
+		if stat, err := entry.Info(); err == nil {
+			info.Size = stat.Size()
+		}
+		profiles = append(profiles, info)
+	}
+
+	sort.Slice(profiles, func(i, j int) bool {
+		if profiles[i].CreatedAt.Equal(profiles[j].CreatedAt) {
+			return profiles[i].Name > profiles[j].Name
+		}
+		return profiles[i].CreatedAt.After(profiles[j].CreatedAt)
+	})
+	return profiles, nil
+}
+
+// Path 获取剖析文件路径
+//
+// 只接受本服务生成的文件名，防止路径穿越。
+func (s *ProfilingService) Path(name string) (string, error) {
+	if _, ok := parseProfileName(name); !ok {
+		return "", ErrProfileNotFound
+	}
+	path := filepath.Join(s.config.Profiling.Path, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", ErrProfileNotFound
+	}
+	return path, nil
+}
+
+// Delete 删除剖析文件
+func (s *ProfilingService) Delete(name string) error {
+	path, err := s.Path(name)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// parseProfileName 从文件名解析剖析文件信息
+func parseProfileName(name string) (ProfileInfo, bool) {
+	matches := profileNamePattern.FindStringSubmatch(name)
+	if matches == nil {
+		return ProfileInfo{}, false
+	}
+	createdAt, err := time.ParseInLocation(profileTimeLayout, matches[2], time.Local)
+	if err != nil {
+		return ProfileInfo{}, false
+	}
+	return ProfileInfo{
+		Name:      name,
+		Type:      matches[1],
+		Reason:    matches[3],
+		CreatedAt: createdAt.Truncate(time.Millisecond),
+	}, true
+}
+
+// sanitizeProfileReason 规范化触发原因，只保留小写字母、数字和下划线
+func sanitizeProfileReason(reason string) string {
+	reason = strings.ToLower(strings.TrimSpace(reason))
+	var builder strings.Builder
+	for _, r := range reason {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_':
+			builder.WriteRune(r)
+		case r == '-' || r == ' ':
+			builder.WriteRune('_')
+		}
+	}
+	if builder.Len() == 0 {
+		return ProfileReasonManual
+	}
+	return builder.String()
+}
@@ -14,12 +14,13 @@ import (
 
 // QueryOptimizationService 查询优化服务
 type QueryOptimizationService struct {
-	db     *gorm.DB
-	config *Config.QueryOptimizationConfig
-	cache  map[string]*QueryAnalysis
-	mu     sync.RWMutex
-	ctx    context.Context
-	cancel context.CancelFunc
+	db       *gorm.DB
+	config   *Config.QueryOptimizationConfig
+	cache    map[string]*QueryAnalysis
+	analyzer *SlowQueryAnalyzer
+	mu       sync.RWMutex
+	ctx      context.Context
+	cancel   context.CancelFunc
 }
 
 // QueryAnalysis 查询分析结果
@@ -45,7 +46,17 @@ type IndexSuggestion struct {
 }
 
 // NewQueryOptimizationService 创建查询优化服务
+//
+// config 为 nil 时使用全局配置，全局配置未加载时使用默认值。
 func NewQueryOptimizationService(db *gorm.DB, config *Config.QueryOptimizationConfig) *QueryOptimizationService {
+	if config == nil {
+		if global := Config.GetConfig(); global != nil {
+			config = &global.QueryOptimization
+		} else {
+			config = &Config.QueryOptimizationConfig{}
+			config.SetDefaults()
+		}
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 
 	service := &QueryOptimizationService{
@@ -56,20 +67,48 @@ func NewQueryOptimizationService(db *gorm.DB, config *Config.QueryOptimizationCo
 		cancel: cancel,
 	}
 
-	service.initialize()
 	return service
 }
 
-// initialize 初始化服务
+// Start 启动查询优化服务
 // 功能说明：
 // 1. 启用数据库慢查询日志记录
 // 2. 启动后台查询分析任务
-// 3. 设置查询性能监控
-func (s *QueryOptimizationService) initialize() {
+// 3. 设置查询性能监控（注册GORM慢查询分析插件）
+// 4. 由应用启动时调用，创建服务不注册GORM回调、不启动后台任务
+func (s *QueryOptimizationService) Start() {
 	s.enableSlowQueryLog()
+	s.enableSlowQueryAnalyzer()
 	go s.startQueryAnalysis()
 }
 
+// enableSlowQueryAnalyzer 注册慢查询分析插件
+// 功能说明：
+// 1. 通过GORM回调记录每条SQL的耗时，按规范化语句聚合
+// 2. 定期把出现过慢查询的语句写入 slow_query_stats 表
+// 3. 注册失败只记录日志，不影响服务启动
+func (s *QueryOptimizationService) enableSlowQueryAnalyzer() {
+	if !s.config.SlowQuery.Enabled {
+		return
+	}
+
+	analyzer := NewSlowQueryAnalyzer(s.config)
+	if err := s.db.Use(analyzer); err != nil {
+		log.Printf("注册慢查询分析插件失败: %v", err)
+		return
+	}
+	if err := analyzer.Start(); err != nil {
+		log.Printf("启动慢查询分析失败: %v", err)
+		return
+	}
+	s.analyzer = analyzer
+}
+
+// SlowQueryAnalyzer 获取慢查询分析器，未启用时返回 nil
+func (s *QueryOptimizationService) SlowQueryAnalyzer() *SlowQueryAnalyzer {
+	return s.analyzer
+}
+
 // enableSlowQueryLog 启用慢查询日志
 // 功能说明：
 // 1. 根据数据库类型启用慢查询日志
@@ -115,7 +154,13 @@ func (s *QueryOptimizationService) analyzeSlowQueries() {
 }
 
 // GetSlowQueries 获取慢查询
+//
+// 启用慢查询分析时返回按P95耗时排序的统计，P95超过 NotificationThreshold 的标记为 CRITICAL。
 func (s *QueryOptimizationService) GetSlowQueries(limit int, warningLevel string) []QueryAnalysis {
+	if s.analyzer != nil {
+		return s.getAnalyzedSlowQueries(limit, warningLevel)
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -132,6 +177,38 @@ func (s *QueryOptimizationService) GetSlowQueries(limit int, warningLevel string
 	return results
 }
 
+// getAnalyzedSlowQueries 从慢查询统计表获取慢查询
+func (s *QueryOptimizationService) getAnalyzedSlowQueries(limit int, warningLevel string) []QueryAnalysis {
+	if err := s.analyzer.Flush(s.ctx); err != nil {
+		log.Printf("写入慢查询统计失败: %v", err)
+	}
+	stats, err := s.analyzer.TopSlowQueries(s.ctx, "p95_time", limit)
+	if err != nil {
+		log.Printf("获取慢查询统计失败: %v", err)
+		return []QueryAnalysis{}
+	}
+
+	results := make([]QueryAnalysis, 0, len(stats))
+	for _, stat := range stats {
+		level := "WARNING"
+		if threshold := s.config.SlowQuery.NotificationThreshold; threshold > 0 &&
+			stat.P95Time >= float64(threshold)/float64(time.Millisecond) {
+			level = "CRITICAL"
+		}
+		if warningLevel != "" && !strings.EqualFold(warningLevel, level) {
+			continue
+		}
+		results = append(results, QueryAnalysis{
+			Query:         stat.NormalizedSQL,
+			ExecutionTime: time.Duration(stat.P95Time * float64(time.Millisecond)),
+			RowsReturned:  stat.RowsAffected,
+			LastAnalyzed:  stat.LastSeenAt,
+			WarningLevel:  level,
+		})
+	}
+	return results
+}
+
 // GetQueryStatistics 获取查询统计信息
 func (s *QueryOptimizationService) GetQueryStatistics() map[string]interface{} {
 	s.mu.RLock()
@@ -231,4 +308,7 @@ func (s *QueryOptimizationService) OptimizeQuery(query string) (string, []string
 // Close 关闭服务
 func (s *QueryOptimizationService) Close() {
 	s.cancel()
+	if s.analyzer != nil {
+		s.analyzer.Stop()
+	}
 }
@@ -30,6 +30,8 @@ func NewRedisService(config *RedisConfig) *RedisService {
 		Password: config.Password,
 		DB:       config.DB,
 	})
+	client.AddHook(FaultInjectionRedisHook{})
+	client.AddHook(DebugRedisHook{})
 
 	return &RedisService{
 		client: client,
@@ -205,7 +207,11 @@ func (r *RedisService) GetStats() (map[string]interface{}, error) {
 
 // Ping 测试Redis连接
 func (r *RedisService) Ping() error {
-	ctx := context.Background()
+	return r.PingContext(context.Background())
+}
+
+// PingContext 使用指定的上下文测试Redis连接，用于启动时等待Redis就绪
+func (r *RedisService) PingContext(ctx context.Context) error {
 	_, err := r.client.Ping(ctx).Result()
 	return err
 }
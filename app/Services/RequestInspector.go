@@ -0,0 +1,433 @@
+package Services
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	mathrand "math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// InspectorAdminPath 请求检查器管理接口路径
+const InspectorAdminPath = "/api/v1/admin/inspector/requests"
+
+// ErrInspectorEntryNotFound 检查器记录不存在
+var ErrInspectorEntryNotFound = errors.New("检查器记录不存在")
+
+// InspectorRecord 中间件采集的一个请求，由后台写入数据库
+type InspectorRecord struct {
+	Request           DebugRequest // 请求执行的SQL、缓存操作、出站调用和通知
+	ClientIP          string
+	RequestHeaders    http.Header
+	RequestBody       []byte
+	RequestTruncated  bool
+	ResponseHeaders   http.Header
+	ResponseBody      []byte
+	ResponseTruncated bool
+	Exception         string
+}
+
+// InspectorEntryDetail 检查器记录详情
+type InspectorEntryDetail struct {
+	Models.InspectorEntry
+	RequestHeaders  map[string][]string   `json:"request_headers"`
+	RequestBody     interface{}           `json:"request_body,omitempty"` // 完整的JSON按JSON返回，其他内容按字符串返回
+	ResponseHeaders map[string][]string   `json:"response_headers"`
+	ResponseBody    interface{}           `json:"response_body,omitempty"`
+	SQL             []DebugQuery          `json:"sql"`
+	Cache           []DebugCacheOperation `json:"cache"`
+	HTTP            []DebugHTTPCall       `json:"http"`
+	Notifications   []DebugNotification   `json:"notifications"`
+}
+
+// InspectorStats 请求检查器统计
+type InspectorStats struct {
+	Recorded int64 `json:"recorded"`
+	Dropped  int64 `json:"dropped"` // 写入队列已满被丢弃的记录数
+	Failed   int64 `json:"failed"`  // 写入数据库失败的记录数
+}
+
+// inspectorDetails 记录的 Details 字段内容
+type inspectorDetails struct {
+	SQL           []DebugQuery          `json:"sql"`
+	Cache         []DebugCacheOperation `json:"cache"`
+	HTTP          []DebugHTTPCall       `json:"http"`
+	Notifications []DebugNotification   `json:"notifications"`
+}
+
+// RequestInspector 请求检查器
+// 功能说明：
+// 1. 中间件在请求开始时调用 Sample 决定是否采样，请求结束后调用 ShouldRecord 按环境和用户决定是否保存
+// 2. 记录放入队列由后台写入 inspector_entries 表，队列已满时丢弃并计数，不阻塞请求
+// 3. 写入前按日志脱敏规则处理请求头、请求体、响应体、SQL和异常信息
+// 4. Purge 删除超过保留时间和超过最大数量的记录，由清理调度器定期运行
+type RequestInspector struct {
+	db          *gorm.DB
+	config      *Config.InspectorConfig
+	masker      *LogMasker
+	allUsers    bool // 当前部署环境对所有用户采样
+	userIDs     map[uint]bool
+	ignorePaths []string
+	random      func() float64
+	queue       chan *InspectorRecord
+
+	recorded int64
+	dropped  int64
+	failed   int64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRequestInspector 创建请求检查器
+//
+// masking 为日志脱敏配置，无论日志脱敏是否启用，保存的记录都会脱敏。
+// environment 为部署环境（server.environment）。
+func NewRequestInspector(db *gorm.DB, config *Config.InspectorConfig, masking Config.LogMaskingConfig, environment string) (*RequestInspector, error) {
+	ids, err := config.UserIDList()
+	if err != nil {
+		return nil, err
+	}
+	masker, err := NewLogMasker(masking)
+	if err != nil {
+		// 自定义规则有误时退回内置规则，与日志脱敏保持一致
+		masking.Patterns = nil
+		if masker, err = NewLogMasker(masking); err != nil {
+			return nil, err
+		}
+	}
+
+	userIDs := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		userIDs[id] = true
+	}
+	allUsers := false
+	for _, item := range config.EnvironmentList() {
+		if item == strings.ToLower(environment) {
+			allUsers = true
+		}
+	}
+	queueSize := config.QueueSize
+	if queueSize <= 0 {
+		queueSize = 1000
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &RequestInspector{
+		db:          db,
+		config:      config,
+		masker:      masker,
+		allUsers:    allUsers,
+		userIDs:     userIDs,
+		ignorePaths: config.IgnorePathList(),
+		random:      mathrand.Float64,
+		queue:       make(chan *InspectorRecord, queueSize),
+		ctx:         ctx,
+		cancel:      cancel,
+	}, nil
+}
+
+// SetRandom 替换采样使用的随机数（用于测试）
+func (i *RequestInspector) SetRandom(random func() float64) {
+	i.random = random
+}
+
+// MaxBodySize 请求体和响应体最多记录的字节数
+func (i *RequestInspector) MaxBodySize() int {
+	return i.config.MaxBodySize
+}
+
+// Ignored 路径是否在不记录的路由分组中
+func (i *RequestInspector) Ignored(path string) bool {
+	for _, prefix := range i.ignorePaths {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Sample 请求开始时决定是否采样，当前环境不对所有用户采样时返回 false
+func (i *RequestInspector) Sample() bool {
+	return i.allUsers && i.random() < i.config.SampleRate
+}
+
+// Watching 是否配置了任何环境都记录的用户，此时未采样的请求也需要采集，认证后再决定是否保存
+func (i *RequestInspector) Watching() bool {
+	return len(i.userIDs) > 0
+}
+
+// ShouldRecord 请求结束后决定是否保存，userID 为认证中间件设置的用户ID，未认证时为空
+func (i *RequestInspector) ShouldRecord(sampled bool, userID string) bool {
+	if sampled {
+		return true
+	}
+	id, err := strconv.ParseUint(userID, 10, 64)
+	return err == nil && i.userIDs[uint(id)]
+}
+
+// Record 放入写入队列，队列已满时丢弃并返回 false
+func (i *RequestInspector) Record(record *InspectorRecord) bool {
+	select {
+	case i.queue <- record:
+		return true
+	default:
+		atomic.AddInt64(&i.dropped, 1)
+		return false
+	}
+}
+
+// Stats 获取统计
+func (i *RequestInspector) Stats() InspectorStats {
+	return InspectorStats{
+		Recorded: atomic.LoadInt64(&i.recorded),
+		Dropped:  atomic.LoadInt64(&i.dropped),
+		Failed:   atomic.LoadInt64(&i.failed),
+	}
+}
+
+// Start 启动后台写入
+func (i *RequestInspector) Start() {
+	i.wg.Add(1)
+	go func() {
+		defer i.wg.Done()
+		for {
+			select {
+			case <-i.ctx.Done():
+				// 关闭前写入队列中剩余的记录
+				for {
+					select {
+					case record := <-i.queue:
+						i.save(record)
+					default:
+						return
+					}
+				}
+			case record := <-i.queue:
+				i.save(record)
+			}
+		}
+	}()
+}
+
+// Close 写入完队列中的记录后停止，最多等待 timeout
+func (i *RequestInspector) Close(timeout time.Duration) {
+	i.cancel()
+	done := make(chan struct{})
+	go func() {
+		i.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
+
+// Flush 同步写入队列中的记录（用于测试和未启动后台写入时）
+func (i *RequestInspector) Flush() {
+	for {
+		select {
+		case record := <-i.queue:
+			i.save(record)
+		default:
+			return
+		}
+	}
+}
+
+// save 脱敏后写入一条记录
+func (i *RequestInspector) save(record *InspectorRecord) {
+	entry, err := i.entry(record)
+	if err == nil {
+		err = i.db.Create(entry).Error
+	}
+	if err != nil {
+		atomic.AddInt64(&i.failed, 1)
+		log.Printf("写入请求检查器记录失败: request_id=%s, error=%v", record.Request.RequestID, err)
+		return
+	}
+	atomic.AddInt64(&i.recorded, 1)
+}
+
+// entry 转换为数据库记录
+func (i *RequestInspector) entry(record *InspectorRecord) (*Models.InspectorEntry, error) {
+	request := record.Request
+	sqls := make([]DebugQuery, len(request.SQL))
+	for n, query := range request.SQL {
+		query.SQL = i.masker.MaskString(query.SQL)
+		query.Error = i.masker.MaskString(query.Error)
+		sqls[n] = query
+	}
+	details, err := json.Marshal(inspectorDetails{
+		SQL:           sqls,
+		Cache:         request.Cache,
+		HTTP:          request.HTTP,
+		Notifications: request.Notifications,
+	})
+	if err != nil {
+		return nil, err
+	}
+	requestHeaders, err := i.headers(record.RequestHeaders)
+	if err != nil {
+		return nil, err
+	}
+	responseHeaders, err := i.headers(record.ResponseHeaders)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &Models.InspectorEntry{
+		RequestID:         request.RequestID,
+		Method:            request.Method,
+		Path:              request.Path,
+		Route:             request.Route,
+		StatusCode:        request.StatusCode,
+		DurationMs:        request.DurationMs,
+		ClientIP:          record.ClientIP,
+		RequestHeaders:    requestHeaders,
+		RequestBody:       i.body(record.RequestBody, record.RequestTruncated),
+		ResponseHeaders:   responseHeaders,
+		ResponseBody:      i.body(record.ResponseBody, record.ResponseTruncated),
+		Exception:         i.masker.MaskString(record.Exception),
+		HasException:      record.Exception != "",
+		QueryCount:        request.Queries,
+		QueryMs:           request.QueryMs,
+		CacheCount:        request.CacheOps,
+		HTTPCount:         request.HTTPCalls,
+		NotificationCount: request.NotificationCount,
+		Details:           string(details),
+		CreatedAt:         request.StartedAt,
+	}
+	if id, err := strconv.ParseUint(request.UserID, 10, 64); err == nil {
+		userID := uint(id)
+		entry.UserID = &userID
+	}
+	return entry, nil
+}
+
+// headers 脱敏请求头或响应头并序列化
+func (i *RequestInspector) headers(header http.Header) (string, error) {
+	if len(header) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(i.masker.MaskValue(header))
+	return string(data), err
+}
+
+// body 脱敏请求体或响应体，完整的JSON按字段脱敏，截断或非JSON内容按正则规则脱敏
+func (i *RequestInspector) body(data []byte, truncated bool) string {
+	if len(data) == 0 {
+		return ""
+	}
+	if !truncated {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var value interface{}
+		if err := decoder.Decode(&value); err == nil && !decoder.More() {
+			if masked, err := json.Marshal(i.masker.MaskValue(value)); err == nil {
+				return string(masked)
+			}
+		}
+	}
+	body := i.masker.MaskString(string(data))
+	if truncated {
+		body += "... [TRUNCATED]"
+	}
+	return body
+}
+
+// List 分页查询记录
+func (i *RequestInspector) List(q *Utils.ListQuery, req Utils.PageRequest) ([]Models.InspectorEntry, Utils.PageMeta, error) {
+	query, order, err := q.Apply(i.db.Model(&Models.InspectorEntry{}), req)
+	if err != nil {
+		return nil, Utils.PageMeta{}, err
+	}
+	var entries []Models.InspectorEntry
+	meta, err := Utils.Paginate(query, req, order, &entries)
+	return entries, meta, err
+}
+
+// Get 获取记录详情
+func (i *RequestInspector) Get(id uint) (*InspectorEntryDetail, error) {
+	var entry Models.InspectorEntry
+	if err := i.db.First(&entry, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInspectorEntryNotFound
+		}
+		return nil, err
+	}
+
+	detail := &InspectorEntryDetail{
+		InspectorEntry:  entry,
+		RequestHeaders:  map[string][]string{},
+		ResponseHeaders: map[string][]string{},
+		RequestBody:     inspectorBodyValue(entry.RequestBody),
+		ResponseBody:    inspectorBodyValue(entry.ResponseBody),
+	}
+	if entry.RequestHeaders != "" {
+		_ = json.Unmarshal([]byte(entry.RequestHeaders), &detail.RequestHeaders)
+	}
+	if entry.ResponseHeaders != "" {
+		_ = json.Unmarshal([]byte(entry.ResponseHeaders), &detail.ResponseHeaders)
+	}
+	var details inspectorDetails
+	if entry.Details != "" {
+		_ = json.Unmarshal([]byte(entry.Details), &details)
+	}
+	detail.SQL = append([]DebugQuery{}, details.SQL...)
+	detail.Cache = append([]DebugCacheOperation{}, details.Cache...)
+	detail.HTTP = append([]DebugHTTPCall{}, details.HTTP...)
+	detail.Notifications = append([]DebugNotification{}, details.Notifications...)
+	return detail, nil
+}
+
+// inspectorBodyValue 保存的JSON按JSON返回，其他内容按字符串返回
+func inspectorBodyValue(body string) interface{} {
+	if body == "" {
+		return nil
+	}
+	if json.Valid([]byte(body)) {
+		return json.RawMessage(body)
+	}
+	return body
+}
+
+// Clear 删除全部记录，返回删除的记录数
+func (i *RequestInspector) Clear() (int64, error) {
+	result := i.db.Where("1 = 1").Delete(&Models.InspectorEntry{})
+	return result.RowsAffected, result.Error
+}
+
+// Purge 分批删除超过保留时间的记录，以及超过最大数量的最早记录，由清理调度器定期运行
+func (i *RequestInspector) Purge(ctx context.Context) (int64, error) {
+	cutoff := time.Now().Add(-i.config.Retention)
+	deleted, err := BatchDelete(ctx, i.db.Where("created_at < ?", cutoff), &Models.InspectorEntry{})
+	if err != nil || i.config.MaxEntries <= 0 {
+		return deleted, err
+	}
+
+	// 保留最新的 MaxEntries 条，更早的记录按主键删除
+	var ids []uint
+	if err := i.db.WithContext(ctx).Model(&Models.InspectorEntry{}).
+		Order("id DESC").Offset(i.config.MaxEntries).Limit(1).Pluck("id", &ids).Error; err != nil {
+		return deleted, err
+	}
+	if len(ids) == 0 {
+		return deleted, nil
+	}
+	excess, err := BatchDelete(ctx, i.db.Where("id <= ?", ids[0]), &Models.InspectorEntry{})
+	return deleted + excess, err
+}
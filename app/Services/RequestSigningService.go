@@ -0,0 +1,501 @@
+package Services
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 请求签名
+// Authorization: HMAC-SHA256 Credential=<key_id>, SignedHeaders=content-type;host, Signature=<hex>
+const (
+	SigningAuthScheme      = "HMAC-SHA256"
+	SigningHeaderTimestamp = "X-Signature-Timestamp" // 签名时间（Unix秒）
+	SigningHeaderNonce     = "X-Signature-Nonce"     // 随机数，同一客户端在时间戳有效期内不能重复
+)
+
+var (
+	// ErrSigningClientNotFound 签名客户端不存在
+	ErrSigningClientNotFound = errors.New("签名客户端不存在")
+	// ErrInvalidSigningClient 签名客户端参数无效
+	ErrInvalidSigningClient = errors.New("签名客户端参数无效")
+	// ErrSignatureInvalid 签名格式错误、客户端不存在或签名不匹配，不区分具体原因
+	ErrSignatureInvalid = errors.New("请求签名无效")
+	// ErrSignatureExpired 请求时间戳超出允许的偏差
+	ErrSignatureExpired = errors.New("请求时间戳已过期")
+	// ErrSignatureReplayed 随机数已被使用
+	ErrSignatureReplayed = errors.New("请求随机数已被使用")
+	// ErrSigningClientDisabled 签名客户端已停用或对应用户已禁用
+	ErrSigningClientDisabled = errors.New("签名客户端已停用")
+	// ErrSignedBodyTooLarge 请求体超过签名验证的大小限制
+	ErrSignedBodyTooLarge = errors.New("请求体过大")
+)
+
+// SigningAuthorization 解析后的签名请求头
+type SigningAuthorization struct {
+	KeyID         string
+	SignedHeaders []string
+	Signature     string
+}
+
+// SigningClientInput 创建和更新签名客户端的参数
+type SigningClientInput struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	UserID      uint   `json:"user_id"` // 请求以该用户身份执行，创建后不能修改
+	Status      string `json:"status"`  // active 或 disabled，创建时为 active
+}
+
+// VerifiedSigningRequest 签名验证通过的请求
+type VerifiedSigningRequest struct {
+	Client *Models.SigningClient
+	User   *Models.User
+}
+
+// RequestSigningService 请求签名认证服务
+// 功能说明：
+// 1. 管理签名客户端，每个客户端有独立的密钥，轮换后旧密钥在宽限期内仍然有效
+// 2. 规范请求为：方法、路径、按键排序的查询参数、SignedHeaders 中的请求头、请求体的SHA-256，逐行拼接
+// 3. 签名内容为 "HMAC-SHA256\n时间戳\n随机数\n" 加规范请求的SHA-256，签名为 HMAC-SHA256(密钥, 签名内容) 的十六进制
+// 4. 时间戳超出允许偏差的请求直接拒绝；签名通过后随机数写入数据库，唯一索引保证多实例下也只能使用一次
+//
+// 安全特性：
+// - 签名使用常量时间比较，客户端不存在和签名不匹配返回相同错误
+// - 签名错误的请求不会占用随机数
+// - 密钥只在创建和轮换时返回
+type RequestSigningService struct {
+	db     *gorm.DB
+	config *Config.RequestSigningConfig
+	now    func() time.Time
+}
+
+// NewRequestSigningService 创建请求签名认证服务，config 为 nil 时使用默认配置
+func NewRequestSigningService(db *gorm.DB, config *Config.RequestSigningConfig) *RequestSigningService {
+	if config == nil {
+		config = &Config.RequestSigningConfig{
+			Enabled:              true,
+			MaxClockSkew:         5 * time.Minute,
+			MaxBodyBytes:         10 * 1024 * 1024,
+			RotationGracePeriod:  24 * time.Hour,
+			NonceCleanupInterval: 10 * time.Minute,
+		}
+	}
+	return &RequestSigningService{db: db, config: config, now: time.Now}
+}
+
+// SetClock 设置时钟（用于测试）
+func (s *RequestSigningService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+var (
+	defaultRequestSigningService   *RequestSigningService
+	defaultRequestSigningServiceMu sync.RWMutex
+)
+
+// SetDefaultRequestSigningService 设置全局请求签名认证服务
+func SetDefaultRequestSigningService(service *RequestSigningService) {
+	defaultRequestSigningServiceMu.Lock()
+	defer defaultRequestSigningServiceMu.Unlock()
+	defaultRequestSigningService = service
+}
+
+// DefaultRequestSigningService 获取全局请求签名认证服务，未启用请求签名时返回 nil
+func DefaultRequestSigningService() *RequestSigningService {
+	defaultRequestSigningServiceMu.RLock()
+	defer defaultRequestSigningServiceMu.RUnlock()
+	return defaultRequestSigningService
+}
+
+// ParseSigningAuthorization 解析 Authorization 请求头
+func ParseSigningAuthorization(header string) (*SigningAuthorization, error) {
+	params, ok := strings.CutPrefix(header, SigningAuthScheme+" ")
+	if !ok {
+		return nil, fmt.Errorf("%w: 认证方式必须为 %s", ErrSignatureInvalid, SigningAuthScheme)
+	}
+	auth := &SigningAuthorization{}
+	for _, part := range strings.Split(params, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: 参数格式错误", ErrSignatureInvalid)
+		}
+		switch name {
+		case "Credential":
+			auth.KeyID = value
+		case "SignedHeaders":
+			for _, h := range strings.Split(value, ";") {
+				if h = strings.ToLower(strings.TrimSpace(h)); h != "" {
+					auth.SignedHeaders = append(auth.SignedHeaders, h)
+				}
+			}
+		case "Signature":
+			auth.Signature = strings.ToLower(value)
+		}
+	}
+	if auth.KeyID == "" || auth.Signature == "" {
+		return nil, fmt.Errorf("%w: 缺少 Credential 或 Signature", ErrSignatureInvalid)
+	}
+	for _, h := range auth.SignedHeaders {
+		if h == "authorization" {
+			return nil, fmt.Errorf("%w: Authorization 不能参与签名", ErrSignatureInvalid)
+		}
+	}
+	return auth, nil
+}
+
+// CanonicalRequest 生成规范请求
+// 格式：方法\n路径\n查询参数\n请求头（每行 名称:值）\n请求头名称（分号分隔）\n请求体SHA-256
+// 查询参数按键和值排序后编码；请求头名称小写排序，多个值用逗号连接，host 取自 req.Host
+func CanonicalRequest(req *http.Request, signedHeaders []string, bodyHash string) string {
+	headers := append([]string(nil), signedHeaders...)
+	sort.Strings(headers)
+
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var pairs []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, name := range headers {
+		values := []string{req.Host}
+		if name != "host" {
+			values = append([]string(nil), req.Header.Values(name)...)
+		}
+		for i := range values {
+			values[i] = strings.TrimSpace(values[i])
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.Join(values, ",") + "\n")
+	}
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	return strings.Join([]string{
+		req.Method,
+		path,
+		strings.Join(pairs, "&"),
+		canonicalHeaders.String(),
+		strings.Join(headers, ";"),
+		bodyHash,
+	}, "\n")
+}
+
+// RequestSignature 计算签名：hex(HMAC-SHA256(secret, "HMAC-SHA256\n" + 时间戳 + "\n" + 随机数 + "\n" + hex(SHA-256(规范请求))))
+func RequestSignature(secret, timestamp, nonce, canonicalRequest string) string {
+	digest := sha256.Sum256([]byte(canonicalRequest))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(SigningAuthScheme + "\n" + timestamp + "\n" + nonce + "\n" + hex.EncodeToString(digest[:])))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignRequest 为请求签名（供Go编写的调用方和测试使用），请求体会被读取后重新设置
+// signedHeaders 为参与签名的请求头，建议包含 host 和 content-type
+func SignRequest(req *http.Request, keyID, secret string, signedHeaders ...string) error {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	headers := make([]string, 0, len(signedHeaders))
+	for _, h := range signedHeaders {
+		headers = append(headers, strings.ToLower(h))
+	}
+	sort.Strings(headers)
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(SigningHeaderTimestamp, timestamp)
+	req.Header.Set(SigningHeaderNonce, hex.EncodeToString(nonce))
+	bodyHash := sha256.Sum256(body)
+	signature := RequestSignature(secret, timestamp, req.Header.Get(SigningHeaderNonce), CanonicalRequest(req, headers, hex.EncodeToString(bodyHash[:])))
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s, SignedHeaders=%s, Signature=%s", SigningAuthScheme, keyID, strings.Join(headers, ";"), signature))
+	return nil
+}
+
+// Verify 验证请求签名，通过后记录随机数并返回客户端和对应用户；请求体读取后重新设置，后续处理器可以正常读取
+func (s *RequestSigningService) Verify(req *http.Request) (*VerifiedSigningRequest, error) {
+	auth, err := ParseSigningAuthorization(req.Header.Get("Authorization"))
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := req.Header.Get(SigningHeaderTimestamp)
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 时间戳格式错误", ErrSignatureInvalid)
+	}
+	signedAt := time.Unix(unix, 0)
+	now := s.now()
+	if skew := now.Sub(signedAt); skew > s.config.MaxClockSkew || skew < -s.config.MaxClockSkew {
+		return nil, ErrSignatureExpired
+	}
+	nonce := req.Header.Get(SigningHeaderNonce)
+	if len(nonce) < 16 || len(nonce) > 128 {
+		return nil, fmt.Errorf("%w: 随机数长度必须为16到128个字符", ErrSignatureInvalid)
+	}
+
+	var client Models.SigningClient
+	if err := s.db.Where("key_id = ?", auth.KeyID).First(&client).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSignatureInvalid
+		}
+		return nil, err
+	}
+
+	var body []byte
+	if req.Body != nil {
+		body, err = io.ReadAll(io.LimitReader(req.Body, s.config.MaxBodyBytes+1))
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(body)) > s.config.MaxBodyBytes {
+			return nil, ErrSignedBodyTooLarge
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	bodyHash := sha256.Sum256(body)
+	canonical := CanonicalRequest(req, auth.SignedHeaders, hex.EncodeToString(bodyHash[:]))
+	if !s.matchSecret(&client, timestamp, nonce, canonical, auth.Signature, now) {
+		return nil, ErrSignatureInvalid
+	}
+	if client.Status != Models.SigningClientActive {
+		return nil, ErrSigningClientDisabled
+	}
+
+	// 随机数在时间戳过期前有效，之后的重放请求会因时间戳被拒绝
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&Models.SigningNonce{
+		ClientID:  client.ID,
+		Nonce:     nonce,
+		ExpiresAt: signedAt.Add(s.config.MaxClockSkew),
+	})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrSignatureReplayed
+	}
+
+	var user Models.User
+	if err := s.db.First(&user, client.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSigningClientDisabled
+		}
+		return nil, err
+	}
+	if user.Status != 1 {
+		return nil, ErrSigningClientDisabled
+	}
+
+	// 最近使用时间每分钟最多更新一次
+	if client.LastUsedAt == nil || now.Sub(*client.LastUsedAt) >= time.Minute {
+		client.LastUsedAt = &now
+		s.db.Model(&Models.SigningClient{}).Where("id = ?", client.ID).UpdateColumn("last_used_at", now)
+	}
+	return &VerifiedSigningRequest{Client: &client, User: &user}, nil
+}
+
+// matchSecret 使用当前密钥和宽限期内的旧密钥验证签名
+func (s *RequestSigningService) matchSecret(client *Models.SigningClient, timestamp, nonce, canonical, signature string, now time.Time) bool {
+	expected := RequestSignature(client.Secret, timestamp, nonce, canonical)
+	if hmac.Equal([]byte(expected), []byte(signature)) {
+		return true
+	}
+	if client.PreviousSecret != "" && client.PreviousSecretExpiresAt != nil && now.Before(*client.PreviousSecretExpiresAt) {
+		expected = RequestSignature(client.PreviousSecret, timestamp, nonce, canonical)
+		return hmac.Equal([]byte(expected), []byte(signature))
+	}
+	return false
+}
+
+// ListClients 获取签名客户端列表
+func (s *RequestSigningService) ListClients() ([]Models.SigningClient, error) {
+	clients := make([]Models.SigningClient, 0)
+	err := s.db.Order("id DESC").Find(&clients).Error
+	return clients, err
+}
+
+// GetClient 获取签名客户端
+func (s *RequestSigningService) GetClient(id uint) (*Models.SigningClient, error) {
+	var client Models.SigningClient
+	if err := s.db.First(&client, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSigningClientNotFound
+		}
+		return nil, err
+	}
+	return &client, nil
+}
+
+// CreateClient 创建签名客户端，返回的客户端 Secret 字段为密钥
+func (s *RequestSigningService) CreateClient(input SigningClientInput, createdBy uint) (*Models.SigningClient, error) {
+	name, description, err := validateSigningClientInput(input)
+	if err != nil {
+		return nil, err
+	}
+	if input.UserID == 0 {
+		return nil, fmt.Errorf("%w: 必须指定调用身份对应的用户", ErrInvalidSigningClient)
+	}
+	var count int64
+	if err := s.db.Model(&Models.User{}).Where("id = ?", input.UserID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("%w: 用户不存在", ErrInvalidSigningClient)
+	}
+	client := &Models.SigningClient{
+		Name:        name,
+		Description: description,
+		KeyID:       generateSigningToken("sk_", 12),
+		Secret:      generateSigningToken("sks_", 32),
+		UserID:      input.UserID,
+		Status:      Models.SigningClientActive,
+		CreatedBy:   createdBy,
+	}
+	if err := s.db.Create(client).Error; err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// UpdateClient 更新签名客户端的名称、描述和状态
+func (s *RequestSigningService) UpdateClient(id uint, input SigningClientInput) (*Models.SigningClient, error) {
+	client, err := s.GetClient(id)
+	if err != nil {
+		return nil, err
+	}
+	name, description, err := validateSigningClientInput(input)
+	if err != nil {
+		return nil, err
+	}
+	status := input.Status
+	if status == "" {
+		status = client.Status
+	}
+	if status != Models.SigningClientActive && status != Models.SigningClientDisabled {
+		return nil, fmt.Errorf("%w: 状态必须为 active 或 disabled", ErrInvalidSigningClient)
+	}
+	err = s.db.Model(client).Updates(map[string]interface{}{
+		"name":        name,
+		"description": description,
+		"status":      status,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.GetClient(id)
+}
+
+// DeleteClient 删除签名客户端及其随机数记录
+func (s *RequestSigningService) DeleteClient(id uint) error {
+	if _, err := s.GetClient(id); err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("client_id = ?", id).Delete(&Models.SigningNonce{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Models.SigningClient{}, id).Error
+	})
+}
+
+// RotateSecret 生成新密钥，返回的客户端 Secret 字段为新密钥
+// 旧密钥在 grace 内仍然有效，grace 为 nil 时使用配置的宽限期，为0时旧密钥立即失效
+func (s *RequestSigningService) RotateSecret(id uint, grace *time.Duration) (*Models.SigningClient, error) {
+	client, err := s.GetClient(id)
+	if err != nil {
+		return nil, err
+	}
+	period := s.config.RotationGracePeriod
+	if grace != nil {
+		period = *grace
+	}
+	if period < 0 {
+		return nil, fmt.Errorf("%w: 宽限期不能为负数", ErrInvalidSigningClient)
+	}
+
+	now := s.now()
+	updates := map[string]interface{}{
+		"secret":                     generateSigningToken("sks_", 32),
+		"previous_secret":            "",
+		"previous_secret_expires_at": nil,
+		"rotated_at":                 now,
+	}
+	if period > 0 {
+		updates["previous_secret"] = client.Secret
+		updates["previous_secret_expires_at"] = now.Add(period)
+	}
+	if err := s.db.Model(client).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	return s.GetClient(id)
+}
+
+// PurgeNonces 分批删除已过期的随机数，返回删除数量，由清理调度器按 NonceCleanupInterval 运行
+func (s *RequestSigningService) PurgeNonces(ctx context.Context) (int64, error) {
+	return BatchDelete(ctx, s.db.Where("expires_at < ?", s.now()), &Models.SigningNonce{})
+}
+
+// NonceCleanupInterval 返回过期随机数的清理间隔
+func (s *RequestSigningService) NonceCleanupInterval() time.Duration {
+	return s.config.NonceCleanupInterval
+}
+
+// validateSigningClientInput 校验名称和描述
+func validateSigningClientInput(input SigningClientInput) (string, string, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len([]rune(name)) > 100 {
+		return "", "", fmt.Errorf("%w: 名称不能为空且不能超过100个字符", ErrInvalidSigningClient)
+	}
+	description := strings.TrimSpace(input.Description)
+	if len([]rune(description)) > 500 {
+		return "", "", fmt.Errorf("%w: 描述不能超过500个字符", ErrInvalidSigningClient)
+	}
+	return name, description, nil
+}
+
+// generateSigningToken 生成带前缀的随机标识或密钥
+func generateSigningToken(prefix string, size int) string {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return prefix + strings.ReplaceAll(uuid.NewString(), "-", "")
+	}
+	return prefix + hex.EncodeToString(buf)
+}
@@ -0,0 +1,337 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMS 待发送的短信
+type SMS struct {
+	To           string            // 接收号码（E.164格式）
+	CountryCode  string            // 国家区号
+	Sender       string            // 发送方：阿里云为短信签名，Twilio为发送号码
+	Content      string            // 替换变量后的短信内容
+	TemplateCode string            // 服务商模板CODE（阿里云）
+	Params       map[string]string // 模板变量
+	OutID        string            // 本地投递记录ID，服务商回执中原样返回
+}
+
+// SMSDriver 短信发送驱动
+//
+// Send 返回服务商的消息ID；失败时返回 *SMSDeliveryError 区分临时失败和永久失败。
+type SMSDriver interface {
+	Name() string
+	Send(ctx context.Context, message *SMS) (string, error)
+}
+
+// SMSDeliveryError 短信发送错误
+type SMSDeliveryError struct {
+	Permanent bool   // 是否永久失败（不再重试）
+	Code      string // 服务商错误码
+	Err       error
+}
+
+func (e *SMSDeliveryError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("%s: %v", e.Code, e.Err)
+	}
+	return e.Err.Error()
+}
+
+func (e *SMSDeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// IsPermanentSMSError 是否为永久失败，未包装的错误（网络错误等）按临时失败处理
+func IsPermanentSMSError(err error) bool {
+	var deliveryErr *SMSDeliveryError
+	return errors.As(err, &deliveryErr) && deliveryErr.Permanent
+}
+
+// NewSMSDriver 按配置创建发送驱动
+//
+// client 为 nil 时使用全局出站HTTP客户端。
+func NewSMSDriver(config *Config.SMSConfig, client *OutboundHTTPClient) (SMSDriver, error) {
+	if config.Driver == Config.SMSDriverLog {
+		return &logSMSDriver{}, nil
+	}
+
+	if client == nil {
+		client = GetOutboundHTTPClient()
+	}
+	// 重试由短信服务负责（阿里云每次请求需要新的签名随机数），出站客户端不再重试
+	policy := client.DefaultPolicy()
+	policy.MaxRetries = 0
+	if config.Timeout > 0 {
+		policy.Timeout = config.Timeout
+	}
+	client.SetPolicy(smsDependency(config.Driver), policy)
+
+	switch config.Driver {
+	case Config.SMSDriverAliyun:
+		return &aliyunSMSDriver{
+			accessKeyID:     config.AccessKeyID,
+			accessKeySecret: config.AccessKeySecret,
+			region:          config.Region,
+			endpoint:        mailEndpoint(config.Endpoint, "https://dysmsapi.aliyuncs.com"),
+			client:          client,
+		}, nil
+	case Config.SMSDriverTwilio:
+		return &twilioSMSDriver{
+			accountSID:          config.AccountSID,
+			authToken:           config.AuthToken,
+			messagingServiceSID: config.MessagingServiceSID,
+			statusCallbackURL:   config.StatusCallbackURL,
+			endpoint:            mailEndpoint(config.Endpoint, "https://api.twilio.com"),
+			client:              client,
+		}, nil
+	default:
+		return nil, fmt.Errorf("不支持的短信驱动: %s", config.Driver)
+	}
+}
+
+// smsDependency 短信服务商在出站HTTP客户端中的依赖名称
+func smsDependency(driver string) string {
+	return "sms_" + driver
+}
+
+// smsHTTPError 将无法解析的服务商HTTP响应转换为发送错误，429和5xx为临时失败，其余4xx为永久失败
+func smsHTTPError(statusCode int, body []byte) error {
+	err := fmt.Errorf("服务商返回状态码 %d: %s", statusCode, strings.TrimSpace(string(body)))
+	permanent := statusCode >= 400 && statusCode < 500 && statusCode != http.StatusTooManyRequests
+	return &SMSDeliveryError{Permanent: permanent, Err: err}
+}
+
+// ---------------------------------------------------------------------------
+// 阿里云短信（RPC风格API，HMAC-SHA1签名）
+// ---------------------------------------------------------------------------
+
+type aliyunSMSDriver struct {
+	accessKeyID     string
+	accessKeySecret string
+	region          string
+	endpoint        string
+	client          *OutboundHTTPClient
+}
+
+func (d *aliyunSMSDriver) Name() string {
+	return Config.SMSDriverAliyun
+}
+
+func (d *aliyunSMSDriver) Send(ctx context.Context, message *SMS) (string, error) {
+	if message.TemplateCode == "" {
+		return "", &SMSDeliveryError{Permanent: true, Err: fmt.Errorf("阿里云短信必须使用已审核的模板，模板未配置服务商模板CODE")}
+	}
+
+	params := map[string]string{
+		"Action":       "SendSms",
+		"Version":      "2017-05-25",
+		"RegionId":     d.region,
+		"PhoneNumbers": aliyunPhoneNumber(message.To, message.CountryCode),
+		"SignName":     message.Sender,
+		"TemplateCode": message.TemplateCode,
+	}
+	if len(message.Params) > 0 {
+		templateParam, err := json.Marshal(message.Params)
+		if err != nil {
+			return "", &SMSDeliveryError{Permanent: true, Err: err}
+		}
+		params["TemplateParam"] = string(templateParam)
+	}
+	if message.OutID != "" {
+		params["OutId"] = message.OutID
+	}
+	body := signAliyunRPCRequest(params, d.accessKeyID, d.accessKeySecret, http.MethodPost, time.Now())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.endpoint+"/", strings.NewReader(body))
+	if err != nil {
+		return "", &SMSDeliveryError{Permanent: true, Err: err}
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := d.client.Do(smsDependency(Config.SMSDriverAliyun), req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	var result struct {
+		Code    string `json:"Code"`
+		Message string `json:"Message"`
+		BizID   string `json:"BizId"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil || result.Code == "" {
+		return "", smsHTTPError(resp.StatusCode, data)
+	}
+	if result.Code != "OK" {
+		return "", &SMSDeliveryError{
+			Permanent: !aliyunRetryableCode(result.Code),
+			Code:      result.Code,
+			Err:       errors.New(result.Message),
+		}
+	}
+	return result.BizID, nil
+}
+
+// aliyunRetryableCode 阿里云错误码是否可重试：服务端错误(isp.*)、流控和限频
+func aliyunRetryableCode(code string) bool {
+	return strings.HasPrefix(code, "isp.") ||
+		strings.Contains(code, "Throttling") ||
+		code == "isv.BUSINESS_LIMIT_CONTROL" ||
+		code == "ServiceUnavailable"
+}
+
+// aliyunPhoneNumber 阿里云号码格式：国内号码不带区号，国际号码为区号加号码（不带+）
+func aliyunPhoneNumber(e164, countryCode string) string {
+	number := strings.TrimPrefix(e164, "+")
+	if countryCode == "86" {
+		return strings.TrimPrefix(number, "86")
+	}
+	return number
+}
+
+// signAliyunRPCRequest 为阿里云RPC风格API添加公共参数和签名，返回表单编码的请求参数
+func signAliyunRPCRequest(params map[string]string, accessKeyID, accessKeySecret, method string, now time.Time) string {
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+
+	signed := make(map[string]string, len(params)+7)
+	for key, value := range params {
+		signed[key] = value
+	}
+	signed["AccessKeyId"] = accessKeyID
+	signed["Format"] = "JSON"
+	signed["SignatureMethod"] = "HMAC-SHA1"
+	signed["SignatureVersion"] = "1.0"
+	signed["SignatureNonce"] = hex.EncodeToString(nonce)
+	signed["Timestamp"] = now.UTC().Format("2006-01-02T15:04:05Z")
+
+	keys := make([]string, 0, len(signed))
+	for key := range signed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, aliyunPercentEncode(key)+"="+aliyunPercentEncode(signed[key]))
+	}
+	canonicalized := strings.Join(pairs, "&")
+
+	stringToSign := method + "&" + aliyunPercentEncode("/") + "&" + aliyunPercentEncode(canonicalized)
+	mac := hmac.New(sha1.New, []byte(accessKeySecret+"&"))
+	mac.Write([]byte(stringToSign))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	return canonicalized + "&Signature=" + aliyunPercentEncode(signature)
+}
+
+// aliyunPercentEncode 阿里云签名要求的URL编码（RFC 3986）
+func aliyunPercentEncode(value string) string {
+	encoded := url.QueryEscape(value)
+	encoded = strings.ReplaceAll(encoded, "+", "%20")
+	encoded = strings.ReplaceAll(encoded, "*", "%2A")
+	return strings.ReplaceAll(encoded, "%7E", "~")
+}
+
+// ---------------------------------------------------------------------------
+// Twilio
+// ---------------------------------------------------------------------------
+
+type twilioSMSDriver struct {
+	accountSID          string
+	authToken           string
+	messagingServiceSID string
+	statusCallbackURL   string
+	endpoint            string
+	client              *OutboundHTTPClient
+}
+
+func (d *twilioSMSDriver) Name() string {
+	return Config.SMSDriverTwilio
+}
+
+func (d *twilioSMSDriver) Send(ctx context.Context, message *SMS) (string, error) {
+	form := url.Values{}
+	form.Set("To", message.To)
+	form.Set("Body", message.Content)
+	// 配置了按国家区号的发送号码时优先使用，否则使用消息服务
+	if message.Sender != "" {
+		form.Set("From", message.Sender)
+	} else if d.messagingServiceSID != "" {
+		form.Set("MessagingServiceSid", d.messagingServiceSID)
+	} else {
+		return "", &SMSDeliveryError{Permanent: true, Err: fmt.Errorf("未配置Twilio发送号码")}
+	}
+	if d.statusCallbackURL != "" {
+		form.Set("StatusCallback", d.statusCallbackURL)
+	}
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", d.endpoint, url.PathEscape(d.accountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", &SMSDeliveryError{Permanent: true, Err: err}
+	}
+	req.SetBasicAuth(d.accountSID, d.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := d.client.Do(smsDependency(Config.SMSDriverTwilio), req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	var result struct {
+		SID     string `json:"sid"`
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	json.Unmarshal(data, &result)
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return result.SID, nil
+	}
+	if result.Code == 0 {
+		return "", smsHTTPError(resp.StatusCode, data)
+	}
+	return "", &SMSDeliveryError{
+		Permanent: resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests,
+		Code:      strconv.Itoa(result.Code),
+		Err:       errors.New(result.Message),
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Log
+// ---------------------------------------------------------------------------
+
+// logSMSDriver 只记录日志不发送
+type logSMSDriver struct{}
+
+func (d *logSMSDriver) Name() string {
+	return Config.SMSDriverLog
+}
+
+func (d *logSMSDriver) Send(ctx context.Context, message *SMS) (string, error) {
+	id := make([]byte, 8)
+	rand.Read(id)
+	messageID := "log-" + hex.EncodeToString(id)
+	log.Printf("[sms] to=%s sender=%q template=%s message_id=%s", message.To, message.Sender, message.TemplateCode, messageID)
+	return messageID, nil
+}
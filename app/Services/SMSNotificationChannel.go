@@ -0,0 +1,93 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SMSNotificationChannel 短信通知通道
+// 功能说明：
+// 1. 使用 monitoring_alert 模板将告警以短信发送到 NotificationConfig.SMS 配置的号码
+// 2. 短信服务商、签名和发送号码使用短信配置（SMS_*），告警通道只配置是否启用和接收号码
+// 3. 短信长度有限，告警消息超过 maxMessageRunes 个字符时截断
+type SMSNotificationChannel struct {
+	smsService      *SMSService
+	phoneNumbers    []string
+	enabled         bool
+	maxMessageRunes int
+}
+
+// NewSMSNotificationChannel 创建短信通知通道
+//
+// smsService 为 nil 时使用全局短信服务。
+func NewSMSNotificationChannel(config *Config.MonitoringConfig, smsService *SMSService) *SMSNotificationChannel {
+	if smsService == nil {
+		smsService = DefaultSMSService()
+	}
+
+	var phoneNumbers []string
+	for _, phone := range strings.Split(config.NotificationConfig.SMS.PhoneNumbers, ",") {
+		if phone = strings.TrimSpace(phone); phone != "" {
+			phoneNumbers = append(phoneNumbers, phone)
+		}
+	}
+
+	return &SMSNotificationChannel{
+		smsService:      smsService,
+		phoneNumbers:    phoneNumbers,
+		enabled:         config.NotificationConfig.SMS.Enabled,
+		maxMessageRunes: 200,
+	}
+}
+
+// SendAlert 发送告警，任一号码发送失败时返回汇总错误
+func (snc *SMSNotificationChannel) SendAlert(alert MonitoringAlert) error {
+	if !snc.enabled {
+		return fmt.Errorf("短信通知通道已禁用")
+	}
+	if snc.smsService == nil {
+		return ErrSMSNotConfigured
+	}
+
+	message := alert.Message
+	if runes := []rune(message); len(runes) > snc.maxMessageRunes {
+		message = string(runes[:snc.maxMessageRunes]) + "..."
+	}
+	vars := map[string]string{
+		"severity": alert.Severity,
+		"title":    alert.Title,
+		"message":  message,
+		"source":   alert.Source,
+		"time":     alert.Timestamp.Format("2006-01-02 15:04:05"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var errs []error
+	for _, phone := range snc.phoneNumbers {
+		if _, err := snc.smsService.Send(ctx, phone, SMSTemplateMonitoringAlert, vars); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", maskPhone(phone), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GetName 获取通道名称
+func (snc *SMSNotificationChannel) GetName() string {
+	return "sms"
+}
+
+// IsEnabled 检查是否启用
+func (snc *SMSNotificationChannel) IsEnabled() bool {
+	return snc.enabled && snc.smsService != nil && len(snc.phoneNumbers) > 0
+}
+
+// SetEnabled 设置启用状态
+func (snc *SMSNotificationChannel) SetEnabled(enabled bool) {
+	snc.enabled = enabled
+}
@@ -0,0 +1,711 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSMSNotConfigured       = errors.New("短信服务未配置")
+	ErrInvalidPhoneNumber     = errors.New("手机号格式无效")
+	ErrSMSTemplateNotFound    = errors.New("短信模板不存在")
+	ErrSMSTemplateDisabled    = errors.New("短信模板已停用")
+	ErrSMSTemplateVariable    = errors.New("短信模板变量缺失")
+	ErrUnsupportedSMSProvider = errors.New("不支持的短信服务商")
+	ErrSMSCodeTooFrequent     = errors.New("验证码发送过于频繁，请稍后再试")
+	ErrSMSCodeInvalid         = errors.New("验证码错误或已过期")
+	ErrSMSCodeTooManyAttempts = errors.New("验证码错误次数过多，请重新获取")
+	ErrSMSPhoneNotBound       = errors.New("用户未绑定手机号")
+)
+
+// 内置短信模板，数据库中存在同名模板时使用数据库中的内容和服务商模板CODE
+const (
+	SMSTemplateVerificationCode = "verification_code" // MFA等场景的验证码
+	SMSTemplateMonitoringAlert  = "monitoring_alert"  // 监控告警
+	SMSTemplatePasswordExpiring = "password_expiring" // 密码即将过期提醒
+)
+
+var builtinSMSTemplates = map[string]string{
+	SMSTemplateVerificationCode: "您的验证码为{{code}}，{{minutes}}分钟内有效，请勿泄露给他人。",
+	SMSTemplateMonitoringAlert:  "【{{severity}}】{{title}}：{{message}}",
+	SMSTemplatePasswordExpiring: "您的账户密码将于{{expires_at}}过期，请尽快登录修改密码。",
+}
+
+// smsTemplateVariable 模板变量，形如 {{name}}
+var smsTemplateVariable = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_]+)\s*\}\}`)
+
+// SMSMessageFilter 短信投递记录查询条件
+type SMSMessageFilter struct {
+	Status string
+	Phone  string
+	Page   int
+	Limit  int
+}
+
+// smsCode 已发送的验证码，只保存哈希
+type smsCode struct {
+	hash      string
+	expiresAt time.Time
+	sentAt    time.Time
+	attempts  int
+}
+
+// SMSService 短信服务
+// 功能说明：
+// 1. 通过阿里云短信或Twilio发送短信，按国家区号选择发送方（签名或发送号码），临时失败按配置次数重试
+// 2. 管理短信模板，内容中的 {{name}} 变量在发送时替换；阿里云按模板CODE和变量发送
+// 3. 每条短信保存投递记录，服务商送达回执更新为已送达或未送达
+// 4. 发送和校验短信验证码，用于MFA验证；验证码只在内存保存哈希，有效期、发送间隔和错误次数受配置限制
+type SMSService struct {
+	db      *gorm.DB
+	config  *Config.SMSConfig
+	senders map[string]string
+
+	mu     sync.RWMutex
+	driver SMSDriver
+
+	codeMu sync.Mutex
+	codes  map[string]*smsCode
+}
+
+// NewSMSService 创建短信服务
+//
+// config 为 nil 时使用全局配置；驱动创建失败时记录日志并退回 log 驱动。
+func NewSMSService(db *gorm.DB, config *Config.SMSConfig) *SMSService {
+	if config == nil {
+		if globalConfig := Config.GetConfig(); globalConfig != nil {
+			config = &globalConfig.SMS
+		} else {
+			config = &Config.SMSConfig{
+				Driver:          Config.SMSDriverLog,
+				DefaultCountry:  "86",
+				MaxRetries:      2,
+				CodeLength:      6,
+				CodeTTL:         5 * time.Minute,
+				CodeInterval:    time.Minute,
+				CodeMaxAttempts: 5,
+			}
+		}
+	}
+
+	var driver SMSDriver = &logSMSDriver{}
+	if config.IsConfigured() {
+		var err error
+		if driver, err = NewSMSDriver(config, nil); err != nil {
+			log.Printf("创建短信驱动失败，使用log驱动: %v", err)
+			driver = &logSMSDriver{}
+		}
+	}
+
+	return &SMSService{
+		db:      db,
+		config:  config,
+		senders: config.SenderMap(),
+		driver:  driver,
+		codes:   make(map[string]*smsCode),
+	}
+}
+
+// SetDriver 替换发送驱动
+func (s *SMSService) SetDriver(driver SMSDriver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.driver = driver
+}
+
+// GetConfig 获取短信配置
+func (s *SMSService) GetConfig() *Config.SMSConfig {
+	return s.config
+}
+
+// NormalizePhone 将号码转换为E.164格式，返回号码和国家区号
+//
+// 带 + 或 00 前缀的号码按国际号码处理，国家区号从发送方配置和默认区号中匹配；
+// 其余号码视为默认国家的本地号码。
+func (s *SMSService) NormalizePhone(phone string) (string, string, error) {
+	phone = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(strings.TrimSpace(phone))
+	international := false
+	switch {
+	case strings.HasPrefix(phone, "+"):
+		phone, international = phone[1:], true
+	case strings.HasPrefix(phone, "00"):
+		phone, international = phone[2:], true
+	}
+	if len(phone) < 5 || len(phone) > 15 {
+		return "", "", ErrInvalidPhoneNumber
+	}
+	for _, r := range phone {
+		if r < '0' || r > '9' {
+			return "", "", ErrInvalidPhoneNumber
+		}
+	}
+
+	if !international {
+		country := strings.TrimPrefix(s.config.DefaultCountry, "+")
+		if country == "" {
+			return "", "", ErrInvalidPhoneNumber
+		}
+		return "+" + country + strings.TrimPrefix(phone, "0"), country, nil
+	}
+
+	// 区号最长3位，优先匹配最长的已知区号
+	for length := 3; length >= 1; length-- {
+		prefix := phone[:length]
+		if _, ok := s.senders[prefix]; ok || prefix == strings.TrimPrefix(s.config.DefaultCountry, "+") {
+			return "+" + phone, prefix, nil
+		}
+	}
+	return "+" + phone, "", nil
+}
+
+// senderFor 按国家区号选择发送方，未配置时使用默认签名或发送号码
+func (s *SMSService) senderFor(countryCode string) string {
+	if sender, ok := s.senders[countryCode]; ok && sender != "" {
+		return sender
+	}
+	if s.config.Driver == Config.SMSDriverTwilio {
+		return s.config.From
+	}
+	return s.config.SignName
+}
+
+// RenderSMSTemplate 替换模板中的 {{name}} 变量，缺少变量时返回 ErrSMSTemplateVariable
+func RenderSMSTemplate(content string, vars map[string]string) (string, error) {
+	var missing []string
+	rendered := smsTemplateVariable.ReplaceAllStringFunc(content, func(match string) string {
+		name := smsTemplateVariable.FindStringSubmatch(match)[1]
+		value, ok := vars[name]
+		if !ok {
+			missing = append(missing, name)
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("%w: %s", ErrSMSTemplateVariable, strings.Join(missing, ","))
+	}
+	return rendered, nil
+}
+
+// GetTemplate 获取模板，数据库中不存在时返回同名的内置模板
+func (s *SMSService) GetTemplate(name string) (*Models.SMSTemplate, error) {
+	var template Models.SMSTemplate
+	err := s.db.Where("name = ?", name).First(&template).Error
+	if err == nil {
+		return &template, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if content, ok := builtinSMSTemplates[name]; ok {
+		return &Models.SMSTemplate{Name: name, Content: content, Enabled: true, Description: "内置模板"}, nil
+	}
+	return nil, ErrSMSTemplateNotFound
+}
+
+// ListTemplates 获取全部模板，包含尚未保存到数据库的内置模板
+func (s *SMSService) ListTemplates() ([]Models.SMSTemplate, error) {
+	var templates []Models.SMSTemplate
+	if err := s.db.Order("name ASC").Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	saved := make(map[string]bool, len(templates))
+	for _, template := range templates {
+		saved[template.Name] = true
+	}
+	for name, content := range builtinSMSTemplates {
+		if !saved[name] {
+			templates = append(templates, Models.SMSTemplate{Name: name, Content: content, Enabled: true, Description: "内置模板"})
+		}
+	}
+	return templates, nil
+}
+
+// SaveTemplate 创建或按名称更新模板
+func (s *SMSService) SaveTemplate(template *Models.SMSTemplate) error {
+	template.Name = strings.TrimSpace(template.Name)
+	if template.Name == "" || strings.TrimSpace(template.Content) == "" {
+		return fmt.Errorf("模板名称和内容不能为空")
+	}
+
+	var existing Models.SMSTemplate
+	err := s.db.Where("name = ?", template.Name).First(&existing).Error
+	switch {
+	case err == nil:
+		template.ID = existing.ID
+		template.CreatedAt = existing.CreatedAt
+		return s.db.Model(&existing).Select("content", "provider_code", "description", "enabled").Updates(template).Error
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		// enabled 字段有默认值，停用状态需要创建后单独更新
+		enabled := template.Enabled
+		if err := s.db.Create(template).Error; err != nil {
+			return err
+		}
+		if !enabled {
+			template.Enabled = false
+			return s.db.Model(template).Update("enabled", false).Error
+		}
+		return nil
+	default:
+		return err
+	}
+}
+
+// DeleteTemplate 删除模板，内置模板删除后恢复默认内容
+func (s *SMSService) DeleteTemplate(name string) error {
+	result := s.db.Where("name = ?", name).Delete(&Models.SMSTemplate{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSMSTemplateNotFound
+	}
+	return nil
+}
+
+// Send 使用模板发送短信
+func (s *SMSService) Send(ctx context.Context, phone, templateName string, vars map[string]string) (*Models.SMSMessage, error) {
+	template, err := s.GetTemplate(templateName)
+	if err != nil {
+		return nil, err
+	}
+	if !template.Enabled {
+		return nil, ErrSMSTemplateDisabled
+	}
+	content, err := RenderSMSTemplate(template.Content, vars)
+	if err != nil {
+		return nil, err
+	}
+	return s.deliver(ctx, phone, template, content, vars, content)
+}
+
+// deliver 保存投递记录并发送，stored 为写入投递记录的内容（验证码短信脱敏）
+func (s *SMSService) deliver(ctx context.Context, phone string, template *Models.SMSTemplate, content string, vars map[string]string, stored string) (*Models.SMSMessage, error) {
+	if !s.config.IsConfigured() {
+		return nil, ErrSMSNotConfigured
+	}
+	to, countryCode, err := s.NormalizePhone(phone)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	driver := s.driver
+	s.mu.RUnlock()
+
+	record := &Models.SMSMessage{
+		Driver:      driver.Name(),
+		Phone:       to,
+		CountryCode: countryCode,
+		Sender:      s.senderFor(countryCode),
+		Template:    template.Name,
+		Content:     stored,
+		Status:      Models.SMSStatusPending,
+	}
+	if err := s.db.Create(record).Error; err != nil {
+		return nil, err
+	}
+
+	message := &SMS{
+		To:           to,
+		CountryCode:  countryCode,
+		Sender:       record.Sender,
+		Content:      content,
+		TemplateCode: template.ProviderCode,
+		Params:       vars,
+		OutID:        strconv.FormatUint(uint64(record.ID), 10),
+	}
+
+	begin := time.Now()
+	backoff := 500 * time.Millisecond
+	var providerID string
+	for attempt := 0; ; attempt++ {
+		record.Attempts++
+		providerID, err = driver.Send(ctx, message)
+		if err == nil || IsPermanentSMSError(err) || attempt >= s.config.MaxRetries || ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+
+	updates := map[string]interface{}{"attempts": record.Attempts}
+	if err != nil {
+		record.Status = Models.SMSStatusFailed
+		record.LastError = err.Error()
+		updates["status"] = record.Status
+		updates["last_error"] = record.LastError
+		var deliveryErr *SMSDeliveryError
+		if errors.As(err, &deliveryErr) {
+			record.ErrorCode = deliveryErr.Code
+			updates["error_code"] = deliveryErr.Code
+		}
+	} else {
+		now := time.Now()
+		record.Status = Models.SMSStatusSent
+		record.ProviderMessageID = providerID
+		record.SentAt = &now
+		updates["status"] = record.Status
+		updates["provider_message_id"] = providerID
+		updates["sent_at"] = now
+	}
+	if dbErr := s.db.Model(record).Updates(updates).Error; dbErr != nil {
+		log.Printf("更新短信投递记录失败: id=%d, error=%v", record.ID, dbErr)
+	}
+	DebugRecorderFromContext(ctx).RecordNotification(DebugNotification{
+		Channel:    "sms",
+		Recipient:  maskPhone(to),
+		Template:   template.Name,
+		Status:     record.Status,
+		DurationMs: debugMs(time.Since(begin)),
+		Error:      record.LastError,
+	}, begin)
+	if err != nil {
+		log.Printf("发送短信失败: id=%d, to=%s, error=%v", record.ID, maskPhone(to), err)
+		return record, err
+	}
+	return record, nil
+}
+
+// ListMessages 查询短信投递记录
+func (s *SMSService) ListMessages(filter SMSMessageFilter) ([]Models.SMSMessage, int64, error) {
+	query := s.db.Model(&Models.SMSMessage{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Phone != "" {
+		query = query.Where("phone LIKE ?", "%"+filter.Phone+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 20
+	}
+	var messages []Models.SMSMessage
+	err := query.Order("id DESC").Offset((filter.Page - 1) * filter.Limit).Limit(filter.Limit).Find(&messages).Error
+	return messages, total, err
+}
+
+// smsReceipt 服务商送达回执
+type smsReceipt struct {
+	ProviderMessageID string
+	OutID             string
+	Delivered         bool
+	Final             bool // 是否为最终状态，Twilio 的 queued/sent 等中间状态不更新记录
+	ErrorCode         string
+	Detail            string
+}
+
+// HandleDeliveryReceipt 处理服务商送达回执，返回更新的投递记录数
+//
+// 阿里云为JSON数组（短信发送状态报告），Twilio 为表单编码的 StatusCallback。
+func (s *SMSService) HandleDeliveryReceipt(provider string, body []byte) (int, error) {
+	var receipts []smsReceipt
+	var err error
+	switch provider {
+	case Config.SMSDriverAliyun:
+		receipts, err = parseAliyunSMSReceipts(body)
+	case Config.SMSDriverTwilio:
+		receipts, err = parseTwilioSMSReceipts(body)
+	default:
+		return 0, ErrUnsupportedSMSProvider
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, receipt := range receipts {
+		if !receipt.Final {
+			continue
+		}
+		status := Models.SMSStatusUndelivered
+		if receipt.Delivered {
+			status = Models.SMSStatusDelivered
+		}
+		query := s.db.Model(&Models.SMSMessage{}).Where("driver = ?", provider)
+		if receipt.ProviderMessageID != "" {
+			query = query.Where("provider_message_id = ?", receipt.ProviderMessageID)
+		} else if id, err := strconv.ParseUint(receipt.OutID, 10, 64); err == nil {
+			query = query.Where("id = ?", id)
+		} else {
+			continue
+		}
+		result := query.Updates(map[string]interface{}{
+			"status":       status,
+			"error_code":   receipt.ErrorCode,
+			"last_error":   receipt.Detail,
+			"delivered_at": time.Now(),
+		})
+		if result.Error != nil {
+			return updated, result.Error
+		}
+		updated += int(result.RowsAffected)
+	}
+	return updated, nil
+}
+
+func parseAliyunSMSReceipts(body []byte) ([]smsReceipt, error) {
+	var reports []struct {
+		Success bool   `json:"success"`
+		ErrCode string `json:"err_code"`
+		ErrMsg  string `json:"err_msg"`
+		BizID   string `json:"biz_id"`
+		OutID   string `json:"out_id"`
+	}
+	if err := json.Unmarshal(body, &reports); err != nil {
+		return nil, fmt.Errorf("解析阿里云短信回执失败: %v", err)
+	}
+
+	receipts := make([]smsReceipt, 0, len(reports))
+	for _, report := range reports {
+		receipt := smsReceipt{ProviderMessageID: report.BizID, OutID: report.OutID, Delivered: report.Success, Final: true}
+		if !report.Success {
+			receipt.ErrorCode = report.ErrCode
+			receipt.Detail = report.ErrMsg
+		}
+		receipts = append(receipts, receipt)
+	}
+	return receipts, nil
+}
+
+func parseTwilioSMSReceipts(body []byte) ([]smsReceipt, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("解析Twilio短信回执失败: %v", err)
+	}
+	sid := values.Get("MessageSid")
+	if sid == "" {
+		sid = values.Get("SmsSid")
+	}
+	if sid == "" {
+		return nil, fmt.Errorf("Twilio短信回执缺少MessageSid")
+	}
+
+	status := values.Get("MessageStatus")
+	if status == "" {
+		status = values.Get("SmsStatus")
+	}
+	receipt := smsReceipt{ProviderMessageID: sid, ErrorCode: values.Get("ErrorCode")}
+	switch status {
+	case "delivered":
+		receipt.Delivered, receipt.Final = true, true
+	case "undelivered", "failed":
+		receipt.Final = true
+		receipt.Detail = "Twilio状态: " + status
+	}
+	return []smsReceipt{receipt}, nil
+}
+
+// SendVerificationCode 向号码发送验证码，purpose 区分使用场景（如 mfa）
+//
+// 同一号码同一场景在发送间隔内不能重复发送，新验证码会使旧验证码失效。
+func (s *SMSService) SendVerificationCode(ctx context.Context, phone, purpose string) error {
+	to, _, err := s.NormalizePhone(phone)
+	if err != nil {
+		return err
+	}
+	key := purpose + "|" + to
+	now := time.Now()
+
+	s.codeMu.Lock()
+	if previous, ok := s.codes[key]; ok && now.Sub(previous.sentAt) < s.config.CodeInterval {
+		s.codeMu.Unlock()
+		return ErrSMSCodeTooFrequent
+	}
+	code, err := randomSMSCode(s.codeLength())
+	if err != nil {
+		s.codeMu.Unlock()
+		return err
+	}
+	s.codes[key] = &smsCode{hash: hashSMSCode(key, code), expiresAt: now.Add(s.codeTTL()), sentAt: now}
+	s.purgeExpiredCodes(now)
+	s.codeMu.Unlock()
+
+	template, err := s.GetTemplate(SMSTemplateVerificationCode)
+	if err != nil {
+		return err
+	}
+	if !template.Enabled {
+		return ErrSMSTemplateDisabled
+	}
+	vars := map[string]string{
+		"code":    code,
+		"minutes": strconv.Itoa(int(s.codeTTL().Minutes())),
+	}
+	content, err := RenderSMSTemplate(template.Content, vars)
+	if err != nil {
+		return err
+	}
+	masked := map[string]string{"code": strings.Repeat("*", len(code)), "minutes": vars["minutes"]}
+	stored, _ := RenderSMSTemplate(template.Content, masked)
+
+	if _, err := s.deliver(ctx, to, template, content, vars, stored); err != nil {
+		// 发送失败时允许立即重新获取
+		s.codeMu.Lock()
+		delete(s.codes, key)
+		s.codeMu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// VerifyCode 校验验证码，校验成功后验证码失效
+func (s *SMSService) VerifyCode(phone, purpose, code string) error {
+	to, _, err := s.NormalizePhone(phone)
+	if err != nil {
+		return err
+	}
+	key := purpose + "|" + to
+
+	s.codeMu.Lock()
+	defer s.codeMu.Unlock()
+	entry, ok := s.codes[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		delete(s.codes, key)
+		return ErrSMSCodeInvalid
+	}
+	if subtle.ConstantTimeCompare([]byte(entry.hash), []byte(hashSMSCode(key, strings.TrimSpace(code)))) != 1 {
+		entry.attempts++
+		if s.config.CodeMaxAttempts > 0 && entry.attempts >= s.config.CodeMaxAttempts {
+			delete(s.codes, key)
+			return ErrSMSCodeTooManyAttempts
+		}
+		return ErrSMSCodeInvalid
+	}
+	delete(s.codes, key)
+	return nil
+}
+
+// SMSPurposeMFA MFA验证码的使用场景
+const SMSPurposeMFA = "mfa"
+
+// SendMFACode 向用户绑定的手机号发送MFA验证码，返回脱敏后的号码
+func (s *SMSService) SendMFACode(ctx context.Context, userID uint) (string, error) {
+	phone, err := s.userPhone(userID)
+	if err != nil {
+		return "", err
+	}
+	if err := s.SendVerificationCode(ctx, phone, SMSPurposeMFA); err != nil {
+		return "", err
+	}
+	normalized, _, _ := s.NormalizePhone(phone)
+	return maskPhone(normalized), nil
+}
+
+// VerifyMFACode 校验用户的MFA验证码
+func (s *SMSService) VerifyMFACode(userID uint, code string) error {
+	phone, err := s.userPhone(userID)
+	if err != nil {
+		return err
+	}
+	return s.VerifyCode(phone, SMSPurposeMFA, code)
+}
+
+// userPhone 获取用户绑定的手机号
+func (s *SMSService) userPhone(userID uint) (string, error) {
+	var user Models.User
+	if err := s.db.Select("id", "phone").First(&user, userID).Error; err != nil {
+		return "", err
+	}
+	if user.Phone == "" {
+		return "", ErrSMSPhoneNotBound
+	}
+	return user.Phone, nil
+}
+
+// purgeExpiredCodes 清理过期验证码，调用方持有 codeMu
+func (s *SMSService) purgeExpiredCodes(now time.Time) {
+	for key, entry := range s.codes {
+		if now.After(entry.expiresAt) && now.Sub(entry.sentAt) >= s.config.CodeInterval {
+			delete(s.codes, key)
+		}
+	}
+}
+
+func (s *SMSService) codeLength() int {
+	if s.config.CodeLength > 0 {
+		return s.config.CodeLength
+	}
+	return 6
+}
+
+func (s *SMSService) codeTTL() time.Duration {
+	if s.config.CodeTTL > 0 {
+		return s.config.CodeTTL
+	}
+	return 5 * time.Minute
+}
+
+// randomSMSCode 生成指定位数的数字验证码
+func randomSMSCode(length int) (string, error) {
+	var b strings.Builder
+	for i := 0; i < length; i++ {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(byte('0' + n.Int64()))
+	}
+	return b.String(), nil
+}
+
+// hashSMSCode 验证码哈希，加入号码和场景避免相同验证码的哈希相同
+func hashSMSCode(key, code string) string {
+	sum := sha256.Sum256([]byte(key + "|" + code))
+	return hex.EncodeToString(sum[:])
+}
+
+// maskPhone 日志中隐藏号码中间四位
+func maskPhone(phone string) string {
+	if len(phone) <= 7 {
+		return phone
+	}
+	return phone[:len(phone)-8] + "****" + phone[len(phone)-4:]
+}
+
+var (
+	defaultSMSMu      sync.RWMutex
+	defaultSMSService *SMSService
+)
+
+// SetDefaultSMSService 设置全局短信服务，监控告警和MFA验证通过它发送短信
+func SetDefaultSMSService(service *SMSService) {
+	defaultSMSMu.Lock()
+	defer defaultSMSMu.Unlock()
+	defaultSMSService = service
+}
+
+// DefaultSMSService 获取全局短信服务，未配置时返回 nil
+func DefaultSMSService() *SMSService {
+	defaultSMSMu.RLock()
+	defer defaultSMSMu.RUnlock()
+	return defaultSMSService
+}
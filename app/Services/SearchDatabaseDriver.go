@@ -0,0 +1,218 @@
+package Services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// DatabaseSearchDriver 数据库搜索驱动
+// 功能说明：
+// 1. 直接查询业务数据表，不需要额外的搜索组件和数据同步
+// 2. MySQL/SQLite 使用 LIKE 匹配（多个查询词之间为 AND，字段之间为 OR）
+// 3. PostgreSQL 使用 to_tsvector/plainto_tsquery 全文检索
+// 4. 相关度和高亮在应用层计算
+//
+// 注意事项：
+// - 适合数据量较小的部署，大数据量请使用 Elasticsearch/OpenSearch 驱动
+// - 索引生命周期操作（建立、删除）在该驱动下没有意义，重建索引只返回文档数
+type DatabaseSearchDriver struct {
+	dbFunc func() *gorm.DB
+}
+
+// NewDatabaseSearchDriver 创建数据库搜索驱动
+func NewDatabaseSearchDriver(dbFunc func() *gorm.DB) *DatabaseSearchDriver {
+	return &DatabaseSearchDriver{dbFunc: dbFunc}
+}
+
+// Name 驱动名称
+func (d *DatabaseSearchDriver) Name() string {
+	return "database"
+}
+
+// Search 执行搜索
+// 功能说明：
+// 1. 每个索引最多取 offset+pageSize 条候选记录
+// 2. 合并所有索引的结果后按相关度、创建时间排序再分页
+// 3. 指定了某个索引不支持的过滤字段时，该索引不参与搜索
+func (d *DatabaseSearchDriver) Search(ctx context.Context, query SearchQuery) (*SearchResult, error) {
+	db := d.dbFunc()
+	if db == nil {
+		return nil, fmt.Errorf("数据库连接不可用")
+	}
+
+	terms := query.Terms()
+	limit := query.Offset() + query.PageSize
+	result := &SearchResult{Totals: make(map[string]int64)}
+	var hits []SearchHit
+
+	for _, name := range query.Indices {
+		index, ok := LookupSearchIndex(name)
+		if !ok {
+			continue
+		}
+		result.Totals[index.Name] = 0
+		if !d.applicable(index, query) || !db.Migrator().HasTable(index.Table) {
+			continue
+		}
+
+		tx := d.buildQuery(db.WithContext(ctx), index, query, terms)
+
+		var total int64
+		if err := tx.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return nil, fmt.Errorf("搜索 %s 失败: %v", index.Name, err)
+		}
+		result.Totals[index.Name] = total
+		result.Total += total
+		if total == 0 {
+			continue
+		}
+
+		var rows []map[string]interface{}
+		if err := tx.Session(&gorm.Session{}).Select(index.Columns).Order("created_at DESC").Limit(limit).Find(&rows).Error; err != nil {
+			return nil, fmt.Errorf("搜索 %s 失败: %v", index.Name, err)
+		}
+
+		for _, row := range rows {
+			hit := SearchHit{
+				Index:     index.Name,
+				ID:        fmt.Sprint(row["id"]),
+				Score:     scoreDocument(index, row, terms),
+				Title:     fmt.Sprint(row[index.TitleField]),
+				Source:    row,
+				CreatedAt: toTime(row["created_at"]),
+			}
+			if query.Highlight {
+				hit.Highlights = buildHighlights(index, row, terms)
+			}
+			hits = append(hits, hit)
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].CreatedAt != nil && hits[j].CreatedAt != nil {
+			return hits[i].CreatedAt.After(*hits[j].CreatedAt)
+		}
+		return false
+	})
+
+	offset := query.Offset()
+	if offset >= len(hits) {
+		result.Hits = []SearchHit{}
+		return result, nil
+	}
+	end := offset + query.PageSize
+	if end > len(hits) {
+		end = len(hits)
+	}
+	result.Hits = hits[offset:end]
+	return result, nil
+}
+
+// applicable 检查索引是否支持查询中的全部过滤字段
+func (d *DatabaseSearchDriver) applicable(index SearchIndex, query SearchQuery) bool {
+	for field := range query.Filters {
+		if !index.HasFilter(field) {
+			return false
+		}
+	}
+	return true
+}
+
+// buildQuery 构建匹配、过滤和时间范围条件
+//
+// 字段名全部来自内置索引定义，不会拼接用户输入。
+func (d *DatabaseSearchDriver) buildQuery(db *gorm.DB, index SearchIndex, query SearchQuery, terms []string) *gorm.DB {
+	tx := db.Table(index.Table).Where("deleted_at IS NULL")
+
+	if db.Dialector.Name() == "postgres" {
+		parts := make([]string, 0, len(index.Fields))
+		for _, field := range index.Fields {
+			parts = append(parts, fmt.Sprintf("coalesce(%s::text, '')", field))
+		}
+		document := strings.Join(parts, " || ' ' || ")
+		tx = tx.Where(fmt.Sprintf("to_tsvector('simple', %s) @@ plainto_tsquery('simple', ?)", document), query.Query)
+	} else {
+		for _, term := range terms {
+			pattern := "%" + escapeLike(term) + "%"
+			conditions := make([]string, 0, len(index.Fields))
+			args := make([]interface{}, 0, len(index.Fields))
+			for _, field := range index.Fields {
+				conditions = append(conditions, field+" LIKE ? ESCAPE '!'")
+				args = append(args, pattern)
+			}
+			tx = tx.Where("("+strings.Join(conditions, " OR ")+")", args...)
+		}
+	}
+
+	for field, value := range query.Filters {
+		tx = tx.Where(field+" = ?", value)
+	}
+	if query.From != nil {
+		tx = tx.Where("created_at >= ?", *query.From)
+	}
+	if query.To != nil {
+		tx = tx.Where("created_at <= ?", *query.To)
+	}
+	return tx
+}
+
+// EnsureIndex 数据库驱动直接使用数据表，无需建立索引
+func (d *DatabaseSearchDriver) EnsureIndex(ctx context.Context, index SearchIndex) error {
+	return nil
+}
+
+// RebuildIndex 数据库驱动无需重建索引，返回当前文档数
+func (d *DatabaseSearchDriver) RebuildIndex(ctx context.Context, index SearchIndex, loader SearchDocumentLoader) (int64, error) {
+	status, err := d.Status(ctx, index)
+	if err != nil {
+		return 0, err
+	}
+	return status.Documents, nil
+}
+
+// DropIndex 数据库驱动的索引就是业务数据表，不允许删除
+func (d *DatabaseSearchDriver) DropIndex(ctx context.Context, index SearchIndex) error {
+	return fmt.Errorf("数据库搜索驱动不支持删除索引")
+}
+
+// IndexDocuments 数据库驱动直接查询数据表，无需写入文档
+func (d *DatabaseSearchDriver) IndexDocuments(ctx context.Context, index SearchIndex, documents []map[string]interface{}) error {
+	return nil
+}
+
+// DeleteDocuments 数据库驱动直接查询数据表，无需删除文档
+func (d *DatabaseSearchDriver) DeleteDocuments(ctx context.Context, index SearchIndex, ids []string) error {
+	return nil
+}
+
+// Status 获取索引状态（数据表是否存在及未删除的记录数）
+func (d *DatabaseSearchDriver) Status(ctx context.Context, index SearchIndex) (*SearchIndexStatus, error) {
+	status := &SearchIndexStatus{Name: index.Name, Driver: d.Name()}
+
+	db := d.dbFunc()
+	if db == nil {
+		return nil, fmt.Errorf("数据库连接不可用")
+	}
+	if !db.Migrator().HasTable(index.Table) {
+		return status, nil
+	}
+
+	status.Exists = true
+	if err := db.WithContext(ctx).Table(index.Table).Where("deleted_at IS NULL").Count(&status.Documents).Error; err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// escapeLike 转义 LIKE 通配符（转义字符为 !）
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+	return replacer.Replace(value)
+}
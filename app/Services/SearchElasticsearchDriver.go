@@ -0,0 +1,599 @@
+package Services
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ElasticsearchSearchDriver Elasticsearch/OpenSearch 搜索驱动
+// 功能说明：
+// 1. 通过REST API访问，兼容 Elasticsearch 7+/8+ 和 OpenSearch
+// 2. 每个逻辑索引对应一个别名（<prefix>-<name>），别名指向带时间戳的实际索引
+// 3. 重建索引时写入新的实际索引，完成后原子切换别名并删除旧索引，搜索不中断
+// 4. 多个节点地址轮询访问，网络错误时自动尝试下一个节点
+// 5. 支持 Basic 认证和 API Key 认证
+type ElasticsearchSearchDriver struct {
+	config *Config.ElasticsearchSearchConfig
+	client *http.Client
+	next   uint32
+}
+
+// NewElasticsearchSearchDriver 创建 Elasticsearch/OpenSearch 搜索驱动
+func NewElasticsearchSearchDriver(config *Config.ElasticsearchSearchConfig) *ElasticsearchSearchDriver {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &ElasticsearchSearchDriver{
+		config: config,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Name 驱动名称
+func (d *ElasticsearchSearchDriver) Name() string {
+	return "elasticsearch"
+}
+
+// alias 获取逻辑索引对应的别名
+func (d *ElasticsearchSearchDriver) alias(index SearchIndex) string {
+	return strings.ToLower(d.config.IndexPrefix + "-" + index.Name)
+}
+
+// backingName 生成新的实际索引名称（别名加纳秒时间戳，保证连续重建时不重名）
+func (d *ElasticsearchSearchDriver) backingName(index SearchIndex) string {
+	return fmt.Sprintf("%s-%d", d.alias(index), time.Now().UnixNano())
+}
+
+// Search 执行搜索
+// 功能说明：
+// 1. multi_match 检索所有全文字段，标题字段权重翻倍，查询词之间为 AND
+// 2. 过滤字段使用 term 过滤，时间范围使用 range 过滤
+// 3. 按 search_index 字段聚合得到每个索引的命中数
+// 4. 高亮使用HTML编码，命中词用 <em></em> 包裹
+func (d *ElasticsearchSearchDriver) Search(ctx context.Context, query SearchQuery) (*SearchResult, error) {
+	aliases := make([]string, 0, len(query.Indices))
+	fieldSet := make(map[string]bool)
+	var fields []string
+	for _, name := range query.Indices {
+		index, ok := LookupSearchIndex(name)
+		if !ok {
+			continue
+		}
+		aliases = append(aliases, d.alias(index))
+		for _, field := range index.Fields {
+			if fieldSet[field] {
+				continue
+			}
+			fieldSet[field] = true
+			if field == index.TitleField {
+				fields = append(fields, field+"^2")
+			} else {
+				fields = append(fields, field)
+			}
+		}
+	}
+
+	filters := []interface{}{}
+	for field, value := range query.Filters {
+		filters = append(filters, map[string]interface{}{"term": map[string]interface{}{d.filterField(query, field): value}})
+	}
+	if query.From != nil || query.To != nil {
+		rangeQuery := map[string]interface{}{}
+		if query.From != nil {
+			rangeQuery["gte"] = query.From.Format(time.RFC3339)
+		}
+		if query.To != nil {
+			rangeQuery["lte"] = query.To.Format(time.RFC3339)
+		}
+		filters = append(filters, map[string]interface{}{"range": map[string]interface{}{"created_at": rangeQuery}})
+	}
+
+	body := map[string]interface{}{
+		"from":             query.Offset(),
+		"size":             query.PageSize,
+		"track_total_hits": true,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": []interface{}{
+					map[string]interface{}{
+						"multi_match": map[string]interface{}{
+							"query":    query.Query,
+							"fields":   fields,
+							"operator": "and",
+						},
+					},
+				},
+				"filter": filters,
+			},
+		},
+		"sort": []interface{}{
+			map[string]interface{}{"_score": "desc"},
+			map[string]interface{}{"created_at": map[string]interface{}{"order": "desc", "unmapped_type": "date"}},
+		},
+		"aggs": map[string]interface{}{
+			"by_index": map[string]interface{}{
+				"terms": map[string]interface{}{"field": "search_index", "size": len(searchIndices)},
+			},
+		},
+	}
+
+	if query.Highlight {
+		highlightFields := make(map[string]interface{})
+		for field := range fieldSet {
+			highlightFields[field] = map[string]interface{}{}
+		}
+		body["highlight"] = map[string]interface{}{
+			"pre_tags":            []string{"<em>"},
+			"post_tags":           []string{"</em>"},
+			"encoder":             "html",
+			"fragment_size":       150,
+			"number_of_fragments": 3,
+			"fields":              highlightFields,
+		}
+	}
+
+	path := "/" + strings.Join(aliases, ",") + "/_search?ignore_unavailable=true&allow_no_indices=true"
+	status, data, err := d.do(ctx, http.MethodPost, path, "application/json", body)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, d.responseError("搜索失败", status, data)
+	}
+
+	var response struct {
+		Hits struct {
+			Total json.RawMessage `json:"total"`
+			Hits  []struct {
+				ID        string                 `json:"_id"`
+				Score     *float64               `json:"_score"`
+				Source    map[string]interface{} `json:"_source"`
+				Highlight map[string][]string    `json:"highlight"`
+			} `json:"hits"`
+		} `json:"hits"`
+		Aggregations struct {
+			ByIndex struct {
+				Buckets []struct {
+					Key      string `json:"key"`
+					DocCount int64  `json:"doc_count"`
+				} `json:"buckets"`
+			} `json:"by_index"`
+		} `json:"aggregations"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("解析搜索结果失败: %v", err)
+	}
+
+	result := &SearchResult{
+		Total:  parseTotalHits(response.Hits.Total),
+		Totals: make(map[string]int64),
+		Hits:   make([]SearchHit, 0, len(response.Hits.Hits)),
+	}
+	for _, name := range query.Indices {
+		result.Totals[name] = 0
+	}
+	for _, bucket := range response.Aggregations.ByIndex.Buckets {
+		result.Totals[bucket.Key] = bucket.DocCount
+	}
+
+	for _, raw := range response.Hits.Hits {
+		indexName, _ := raw.Source["search_index"].(string)
+		delete(raw.Source, "search_index")
+		index, _ := LookupSearchIndex(indexName)
+
+		hit := SearchHit{
+			Index:      indexName,
+			ID:         raw.ID,
+			Source:     raw.Source,
+			Highlights: raw.Highlight,
+			CreatedAt:  toTime(raw.Source["created_at"]),
+		}
+		if raw.Score != nil {
+			hit.Score = *raw.Score
+		}
+		if title, ok := raw.Source[index.TitleField]; ok && title != nil {
+			hit.Title = fmt.Sprint(title)
+		}
+		result.Hits = append(result.Hits, hit)
+	}
+
+	return result, nil
+}
+
+// filterField 获取过滤使用的字段名
+//
+// 同时用于全文检索的字段映射为 text，精确过滤需要使用其 keyword 子字段。
+func (d *ElasticsearchSearchDriver) filterField(query SearchQuery, field string) string {
+	for _, name := range query.Indices {
+		index, ok := LookupSearchIndex(name)
+		if ok && index.HasFilter(field) && containsString(index.Fields, field) {
+			return field + ".raw"
+		}
+	}
+	return field
+}
+
+// EnsureIndex 确保别名存在，不存在时创建实际索引并绑定别名
+func (d *ElasticsearchSearchDriver) EnsureIndex(ctx context.Context, index SearchIndex) error {
+	status, _, err := d.do(ctx, http.MethodHead, "/"+d.alias(index), "", nil)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusOK {
+		return nil
+	}
+
+	return d.createIndex(ctx, index, d.backingName(index), true)
+}
+
+// RebuildIndex 重建索引
+// 功能说明：
+// 1. 创建新的实际索引（不绑定别名），写入 loader 提供的全部文档
+// 2. 刷新新索引后，在一个 _aliases 请求中把别名从旧索引移到新索引
+// 3. 删除旧的实际索引
+// 4. 任一步骤失败时删除新索引，别名仍指向旧索引
+func (d *ElasticsearchSearchDriver) RebuildIndex(ctx context.Context, index SearchIndex, loader SearchDocumentLoader) (int64, error) {
+	alias := d.alias(index)
+	backing := d.backingName(index)
+	if err := d.createIndex(ctx, index, backing, false); err != nil {
+		return 0, err
+	}
+
+	var count int64
+	err := loader(ctx, func(documents []map[string]interface{}) error {
+		if err := d.bulkIndex(ctx, index, backing, documents); err != nil {
+			return err
+		}
+		count += int64(len(documents))
+		return nil
+	})
+	if err == nil {
+		err = d.expectOK(ctx, http.MethodPost, "/"+backing+"/_refresh", nil, "刷新索引失败")
+	}
+
+	var previous []string
+	if err == nil {
+		previous, err = d.backingIndices(ctx, alias)
+	}
+	if err == nil {
+		actions := []interface{}{}
+		for _, old := range previous {
+			actions = append(actions, map[string]interface{}{"remove": map[string]interface{}{"index": old, "alias": alias}})
+		}
+		actions = append(actions, map[string]interface{}{"add": map[string]interface{}{"index": backing, "alias": alias}})
+		err = d.expectOK(ctx, http.MethodPost, "/_aliases", map[string]interface{}{"actions": actions}, "切换索引别名失败")
+	}
+	if err != nil {
+		d.do(ctx, http.MethodDelete, "/"+backing, "", nil)
+		return 0, err
+	}
+
+	for _, old := range previous {
+		if old != backing {
+			d.do(ctx, http.MethodDelete, "/"+old, "", nil)
+		}
+	}
+	return count, nil
+}
+
+// DropIndex 删除别名指向的全部实际索引
+func (d *ElasticsearchSearchDriver) DropIndex(ctx context.Context, index SearchIndex) error {
+	backings, err := d.backingIndices(ctx, d.alias(index))
+	if err != nil {
+		return err
+	}
+	for _, backing := range backings {
+		if err := d.expectOK(ctx, http.MethodDelete, "/"+backing, nil, "删除索引失败"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IndexDocuments 写入或更新文档（写入别名）
+func (d *ElasticsearchSearchDriver) IndexDocuments(ctx context.Context, index SearchIndex, documents []map[string]interface{}) error {
+	return d.bulkIndex(ctx, index, d.alias(index), documents)
+}
+
+// DeleteDocuments 删除文档
+func (d *ElasticsearchSearchDriver) DeleteDocuments(ctx context.Context, index SearchIndex, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var buffer bytes.Buffer
+	alias := d.alias(index)
+	for _, id := range ids {
+		action, _ := json.Marshal(map[string]interface{}{"delete": map[string]interface{}{"_index": alias, "_id": id}})
+		buffer.Write(action)
+		buffer.WriteByte('\n')
+	}
+	return d.bulk(ctx, buffer.Bytes())
+}
+
+// Status 获取别名指向的实际索引和文档数
+func (d *ElasticsearchSearchDriver) Status(ctx context.Context, index SearchIndex) (*SearchIndexStatus, error) {
+	alias := d.alias(index)
+	status := &SearchIndexStatus{Name: index.Name, Driver: d.Name()}
+
+	backings, err := d.backingIndices(ctx, alias)
+	if err != nil {
+		return nil, err
+	}
+	if len(backings) == 0 {
+		return status, nil
+	}
+	status.Exists = true
+	status.Backing = strings.Join(backings, ",")
+
+	code, data, err := d.do(ctx, http.MethodGet, "/"+alias+"/_count", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	if code != http.StatusOK {
+		return nil, d.responseError("获取文档数失败", code, data)
+	}
+	var response struct {
+		Count int64 `json:"count"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("解析文档数失败: %v", err)
+	}
+	status.Documents = response.Count
+	return status, nil
+}
+
+// createIndex 创建实际索引
+func (d *ElasticsearchSearchDriver) createIndex(ctx context.Context, index SearchIndex, name string, withAlias bool) error {
+	properties := map[string]interface{}{
+		"search_index": map[string]interface{}{"type": "keyword"},
+		"id":           map[string]interface{}{"type": "keyword"},
+		"created_at":   map[string]interface{}{"type": "date"},
+		"updated_at":   map[string]interface{}{"type": "date"},
+	}
+	for _, field := range index.Filters {
+		properties[field] = map[string]interface{}{"type": "keyword"}
+	}
+	for _, field := range index.Fields {
+		if index.HasFilter(field) {
+			// 既可检索又可过滤的字段：text 主字段 + keyword 子字段
+			properties[field] = map[string]interface{}{
+				"type":   "text",
+				"fields": map[string]interface{}{"raw": map[string]interface{}{"type": "keyword", "ignore_above": 256}},
+			}
+			continue
+		}
+		properties[field] = map[string]interface{}{"type": "text"}
+	}
+
+	shards := d.config.Shards
+	if shards <= 0 {
+		shards = 1
+	}
+	body := map[string]interface{}{
+		"settings": map[string]interface{}{
+			"number_of_shards":   shards,
+			"number_of_replicas": d.config.Replicas,
+		},
+		"mappings": map[string]interface{}{
+			"properties": properties,
+		},
+	}
+	if withAlias {
+		body["aliases"] = map[string]interface{}{d.alias(index): map[string]interface{}{}}
+	}
+
+	return d.expectOK(ctx, http.MethodPut, "/"+name, body, "创建索引失败")
+}
+
+// backingIndices 获取别名指向的实际索引，别名不存在时返回空列表
+func (d *ElasticsearchSearchDriver) backingIndices(ctx context.Context, alias string) ([]string, error) {
+	status, data, err := d.do(ctx, http.MethodGet, "/_alias/"+alias, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if status != http.StatusOK {
+		return nil, d.responseError("获取索引别名失败", status, data)
+	}
+
+	var response map[string]json.RawMessage
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("解析索引别名失败: %v", err)
+	}
+	backings := make([]string, 0, len(response))
+	for name := range response {
+		backings = append(backings, name)
+	}
+	sort.Strings(backings)
+	return backings, nil
+}
+
+// bulkIndex 批量写入文档
+func (d *ElasticsearchSearchDriver) bulkIndex(ctx context.Context, index SearchIndex, target string, documents []map[string]interface{}) error {
+	if len(documents) == 0 {
+		return nil
+	}
+
+	var buffer bytes.Buffer
+	for _, document := range documents {
+		source := make(map[string]interface{}, len(document)+1)
+		for key, value := range document {
+			if raw, ok := value.([]byte); ok {
+				value = string(raw)
+			}
+			source[key] = value
+		}
+		source["search_index"] = index.Name
+
+		action, err := json.Marshal(map[string]interface{}{"index": map[string]interface{}{"_index": target, "_id": fmt.Sprint(document["id"])}})
+		if err != nil {
+			return err
+		}
+		line, err := json.Marshal(source)
+		if err != nil {
+			return fmt.Errorf("序列化文档失败: %v", err)
+		}
+		buffer.Write(action)
+		buffer.WriteByte('\n')
+		buffer.Write(line)
+		buffer.WriteByte('\n')
+	}
+	return d.bulk(ctx, buffer.Bytes())
+}
+
+// bulk 发送 _bulk 请求并检查逐条结果
+func (d *ElasticsearchSearchDriver) bulk(ctx context.Context, payload []byte) error {
+	status, data, err := d.do(ctx, http.MethodPost, "/_bulk", "application/x-ndjson", payload)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return d.responseError("批量写入失败", status, data)
+	}
+
+	var response struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int             `json:"status"`
+			Error  json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return fmt.Errorf("解析批量写入结果失败: %v", err)
+	}
+	if !response.Errors {
+		return nil
+	}
+
+	failed := 0
+	var first string
+	for _, item := range response.Items {
+		for _, result := range item {
+			// 删除不存在的文档返回404，不视为失败
+			if result.Status >= 300 && result.Status != http.StatusNotFound {
+				failed++
+				if first == "" {
+					first = string(result.Error)
+				}
+			}
+		}
+	}
+	if failed == 0 {
+		return nil
+	}
+	return fmt.Errorf("批量写入失败 %d 条: %s", failed, first)
+}
+
+// expectOK 发送请求并要求返回2xx
+func (d *ElasticsearchSearchDriver) expectOK(ctx context.Context, method, path string, body interface{}, message string) error {
+	status, data, err := d.do(ctx, method, path, "application/json", body)
+	if err != nil {
+		return err
+	}
+	if status < 200 || status >= 300 {
+		return d.responseError(message, status, data)
+	}
+	return nil
+}
+
+// do 发送请求
+//
+// 按轮询顺序选择节点，网络错误时尝试下一个节点，所有节点都失败时返回最后一个错误。
+func (d *ElasticsearchSearchDriver) do(ctx context.Context, method, path, contentType string, body interface{}) (int, []byte, error) {
+	var payload []byte
+	switch v := body.(type) {
+	case nil:
+	case []byte:
+		payload = v
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return 0, nil, fmt.Errorf("序列化请求失败: %v", err)
+		}
+		payload = encoded
+	}
+
+	addresses := d.config.Addresses
+	if len(addresses) == 0 {
+		return 0, nil, fmt.Errorf("搜索引擎地址未配置")
+	}
+
+	start := int(atomic.AddUint32(&d.next, 1))
+	var lastErr error
+	for i := 0; i < len(addresses); i++ {
+		address := strings.TrimRight(addresses[(start+i)%len(addresses)], "/")
+
+		var reader io.Reader
+		if payload != nil {
+			reader = bytes.NewReader(payload)
+		}
+		request, err := http.NewRequestWithContext(ctx, method, address+path, reader)
+		if err != nil {
+			return 0, nil, err
+		}
+		if contentType != "" && payload != nil {
+			request.Header.Set("Content-Type", contentType)
+		}
+		if d.config.APIKey != "" {
+			request.Header.Set("Authorization", "ApiKey "+d.config.APIKey)
+		} else if d.config.Username != "" {
+			request.SetBasicAuth(d.config.Username, d.config.Password)
+		}
+
+		response, err := d.client.Do(request)
+		if err != nil {
+			lastErr = err
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		data, err := io.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return response.StatusCode, data, nil
+	}
+
+	return 0, nil, fmt.Errorf("搜索引擎请求失败: %v", lastErr)
+}
+
+// responseError 根据响应构造错误
+func (d *ElasticsearchSearchDriver) responseError(message string, status int, data []byte) error {
+	detail := strings.TrimSpace(string(data))
+	if len(detail) > 500 {
+		detail = detail[:500]
+	}
+	return fmt.Errorf("%s (HTTP %d): %s", message, status, detail)
+}
+
+// parseTotalHits 解析命中总数（兼容 ES6 数字格式和 ES7+ 对象格式）
+func parseTotalHits(raw json.RawMessage) int64 {
+	if len(raw) == 0 {
+		return 0
+	}
+	var total struct {
+		Value int64 `json:"value"`
+	}
+	if err := json.Unmarshal(raw, &total); err == nil {
+		return total.Value
+	}
+	var count int64
+	json.Unmarshal(raw, &count)
+	return count
+}
@@ -0,0 +1,751 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/I18n"
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// SearchIndex 可搜索的数据源定义
+//
+// 每个索引对应一张数据表，数据库驱动直接查询该表，
+// 搜索引擎驱动则按此定义建立映射并同步文档。
+type SearchIndex struct {
+	Name       string   `json:"name"`        // 索引名称（users、posts、audit_logs、security_events）
+	Table      string   `json:"table"`       // 数据表
+	TitleField string   `json:"title_field"` // 作为结果标题的字段
+	Fields     []string `json:"fields"`      // 全文检索字段（第一个字段权重最高）
+	Filters    []string `json:"filters"`     // 允许精确过滤的字段
+	Columns    []string `json:"columns"`     // 返回和同步的字段（不包含密码等敏感字段）
+	AdminOnly  bool     `json:"admin_only"`  // 是否仅管理员可搜索
+}
+
+// searchIndices 内置索引定义
+var searchIndices = []SearchIndex{
+	{
+		Name:       "users",
+		Table:      "users",
+		TitleField: "username",
+		Fields:     []string{"username", "email"},
+		Filters:    []string{"role", "status"},
+		Columns:    []string{"id", "username", "email", "avatar", "role", "status", "created_at", "updated_at"},
+		AdminOnly:  true,
+	},
+	{
+		Name:       "posts",
+		Table:      "posts",
+		TitleField: "title",
+		Fields:     []string{"title", "summary", "content"},
+		Filters:    []string{"status", "user_id", "category_id"},
+		Columns:    []string{"id", "title", "summary", "content", "status", "user_id", "category_id", "created_at", "updated_at"},
+	},
+	{
+		Name:       "audit_logs",
+		Table:      "audit_logs",
+		TitleField: "action",
+		Fields:     []string{"action", "description", "resource", "username"},
+		Filters:    []string{"user_id", "level", "action", "resource", "status"},
+		Columns:    []string{"id", "user_id", "username", "action", "level", "resource", "resource_id", "description", "ip_address", "status", "created_at", "updated_at"},
+		AdminOnly:  true,
+	},
+	{
+		Name:       "security_events",
+		Table:      "security_events",
+		TitleField: "event_type",
+		Fields:     []string{"event_type", "details", "username", "resource", "action", "ip_address"},
+		Filters:    []string{"event_type", "event_level", "user_id", "ip_address"},
+		Columns:    []string{"id", "event_type", "event_level", "user_id", "username", "ip_address", "resource", "action", "details", "risk_score", "created_at", "updated_at"},
+		AdminOnly:  true,
+	},
+}
+
+// SearchIndices 获取所有索引定义
+func SearchIndices() []SearchIndex {
+	indices := make([]SearchIndex, len(searchIndices))
+	copy(indices, searchIndices)
+	return indices
+}
+
+// LookupSearchIndex 按名称查找索引定义
+func LookupSearchIndex(name string) (SearchIndex, bool) {
+	for _, index := range searchIndices {
+		if index.Name == name {
+			return index, true
+		}
+	}
+	return SearchIndex{}, false
+}
+
+// HasFilter 检查字段是否允许过滤
+func (i SearchIndex) HasFilter(field string) bool {
+	for _, filter := range i.Filters {
+		if filter == field {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchQuery 搜索请求
+type SearchQuery struct {
+	Query     string            `json:"query"`
+	Indices   []string          `json:"indices"`
+	Filters   map[string]string `json:"filters"`
+	From      *time.Time        `json:"from,omitempty"`
+	To        *time.Time        `json:"to,omitempty"`
+	Page      int               `json:"page"`
+	PageSize  int               `json:"page_size"`
+	Highlight bool              `json:"highlight"`
+}
+
+// Terms 获取查询词（按空白拆分，去重）
+func (q SearchQuery) Terms() []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, term := range strings.Fields(q.Query) {
+		lower := strings.ToLower(term)
+		if !seen[lower] {
+			seen[lower] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// Offset 获取分页偏移量
+func (q SearchQuery) Offset() int {
+	return (q.Page - 1) * q.PageSize
+}
+
+// SearchHit 单条搜索结果
+type SearchHit struct {
+	Index      string                 `json:"index"`
+	ID         string                 `json:"id"`
+	Score      float64                `json:"score"`
+	Title      string                 `json:"title"`
+	Highlights map[string][]string    `json:"highlights,omitempty"`
+	Source     map[string]interface{} `json:"source"`
+	CreatedAt  *time.Time             `json:"created_at,omitempty"`
+}
+
+// SearchResult 搜索结果
+type SearchResult struct {
+	Hits     []SearchHit      `json:"hits"`
+	Total    int64            `json:"total"`
+	Totals   map[string]int64 `json:"totals"`
+	Page     int              `json:"page"`
+	PageSize int              `json:"page_size"`
+	Took     time.Duration    `json:"took"`
+	Driver   string           `json:"driver"`
+}
+
+// SearchIndexStatus 索引状态
+type SearchIndexStatus struct {
+	Name       string     `json:"name"`
+	Driver     string     `json:"driver"`
+	Exists     bool       `json:"exists"`
+	Documents  int64      `json:"documents"`
+	Backing    string     `json:"backing,omitempty"` // 搜索引擎中别名指向的实际索引
+	LastSyncAt *time.Time `json:"last_sync_at,omitempty"`
+}
+
+// SearchDriver 搜索驱动接口
+//
+// 驱动只负责检索和索引存储，权限控制、参数规范化和数据同步由 SearchService 处理。
+type SearchDriver interface {
+	// Name 驱动名称
+	Name() string
+	// Search 执行搜索，query 已经过规范化
+	Search(ctx context.Context, query SearchQuery) (*SearchResult, error)
+	// EnsureIndex 确保索引存在
+	EnsureIndex(ctx context.Context, index SearchIndex) error
+	// RebuildIndex 使用 loader 提供的文档重建索引
+	RebuildIndex(ctx context.Context, index SearchIndex, loader SearchDocumentLoader) (int64, error)
+	// DropIndex 删除索引
+	DropIndex(ctx context.Context, index SearchIndex) error
+	// IndexDocuments 写入或更新文档
+	IndexDocuments(ctx context.Context, index SearchIndex, documents []map[string]interface{}) error
+	// DeleteDocuments 删除文档
+	DeleteDocuments(ctx context.Context, index SearchIndex, ids []string) error
+	// Status 获取索引状态
+	Status(ctx context.Context, index SearchIndex) (*SearchIndexStatus, error)
+}
+
+// SearchDocumentLoader 按批次提供文档，handle 返回错误时停止加载
+type SearchDocumentLoader func(ctx context.Context, handle func(documents []map[string]interface{}) error) error
+
+// SearchService 全文搜索服务
+// 功能说明：
+// 1. 对用户、文章、审计日志和安全事件提供统一的全文搜索
+// 2. 数据库驱动（LIKE，PostgreSQL使用tsvector）适合小规模部署，无需额外组件
+// 3. Elasticsearch/OpenSearch驱动适合大规模数据，支持别名切换的零停机重建索引
+// 4. 搜索引擎驱动下后台按更新时间增量同步，软删除的记录会从索引中移除
+// 5. 支持分页、高亮、字段过滤和时间范围过滤
+type SearchService struct {
+	BaseService
+	config *Config.SearchConfig
+	driver SearchDriver
+
+	mu       sync.RWMutex
+	lastSync map[string]time.Time
+	ctx      context.Context
+	cancel   context.CancelFunc
+	started  bool
+}
+
+// NewSearchService 创建搜索服务
+// 功能说明：
+// 1. 配置为空时使用全局搜索配置，全局配置也未加载时使用数据库驱动
+// 2. 根据配置选择数据库驱动或Elasticsearch/OpenSearch驱动
+func NewSearchService(config *Config.SearchConfig) *SearchService {
+	if config == nil {
+		config = Config.GetSearchConfig()
+	}
+	if config == nil {
+		config = &Config.SearchConfig{Driver: "database", DefaultPageSize: 10, MaxPageSize: 100, SyncBatchSize: 500}
+	}
+
+	service := &SearchService{
+		BaseService: *NewBaseService(),
+		config:      config,
+		lastSync:    make(map[string]time.Time),
+	}
+
+	switch config.DriverName() {
+	case "elasticsearch", "opensearch":
+		service.driver = NewElasticsearchSearchDriver(&config.Elasticsearch)
+	default:
+		service.driver = NewDatabaseSearchDriver(service.getDB)
+	}
+
+	return service
+}
+
+// NewSearchServiceWithDriver 使用指定驱动和数据库连接创建搜索服务（用于测试和自定义驱动）
+//
+// driver 为空时按配置选择驱动，db 为空时使用全局数据库连接。
+func NewSearchServiceWithDriver(config *Config.SearchConfig, driver SearchDriver, db *gorm.DB) *SearchService {
+	service := NewSearchService(config)
+	if driver != nil {
+		service.driver = driver
+	}
+	if db != nil {
+		service.DB = db
+	}
+	return service
+}
+
+// getDB 获取数据库连接
+func (s *SearchService) getDB() *gorm.DB {
+	if s.DB != nil {
+		if db, ok := s.DB.(*gorm.DB); ok {
+			return db
+		}
+	}
+	return Database.DB
+}
+
+// Driver 获取当前搜索驱动
+func (s *SearchService) Driver() SearchDriver {
+	return s.driver
+}
+
+// Start 启动搜索服务
+// 功能说明：
+// 1. 数据库驱动无需初始化，直接返回
+// 2. 搜索引擎驱动下确保所有索引存在
+// 3. 按 SyncInterval 启动后台增量同步
+func (s *SearchService) Start() error {
+	if s.driver.Name() == "database" {
+		return nil
+	}
+
+	s.mu.Lock()
+	if s.started {
+		s.mu.Unlock()
+		return nil
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.started = true
+	s.mu.Unlock()
+
+	for _, index := range searchIndices {
+		if err := s.driver.EnsureIndex(s.ctx, index); err != nil {
+			return fmt.Errorf("初始化搜索索引 %s 失败: %v", index.Name, err)
+		}
+	}
+
+	if s.config.SyncInterval > 0 {
+		go s.syncLoop()
+	}
+	return nil
+}
+
+// Stop 停止后台同步
+func (s *SearchService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.started = false
+}
+
+// Search 执行搜索
+// 功能说明：
+// 1. 规范化分页参数（默认和最大分页大小来自配置）
+// 2. 未指定索引时搜索 allowed 中的全部索引
+// 3. 校验索引名称和过滤字段，未知过滤字段直接拒绝
+//
+// 参数说明：
+// - allowed: 调用方有权搜索的索引，为空表示全部
+func (s *SearchService) Search(ctx context.Context, query SearchQuery, allowed []string) (*SearchResult, error) {
+	query.Query = strings.TrimSpace(query.Query)
+	if query.Query == "" {
+		return nil, I18n.NewError("search.query_required", "搜索关键词不能为空")
+	}
+
+	if len(allowed) == 0 {
+		for _, index := range searchIndices {
+			allowed = append(allowed, index.Name)
+		}
+	}
+	if len(query.Indices) == 0 {
+		query.Indices = allowed
+	}
+	for _, name := range query.Indices {
+		if _, ok := LookupSearchIndex(name); !ok {
+			return nil, I18n.NewError("search.unknown_index", "未知的搜索索引: :index", I18n.Params{"index": name})
+		}
+		if !containsString(allowed, name) {
+			return nil, I18n.NewError("search.index_forbidden", "无权搜索索引: :index", I18n.Params{"index": name})
+		}
+	}
+
+	for field := range query.Filters {
+		supported := false
+		for _, name := range query.Indices {
+			index, _ := LookupSearchIndex(name)
+			if index.HasFilter(field) {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return nil, I18n.NewError("search.filter_unsupported", "不支持的过滤字段: :field", I18n.Params{"field": field})
+		}
+	}
+
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PageSize < 1 {
+		query.PageSize = s.config.DefaultPageSize
+		if query.PageSize < 1 {
+			query.PageSize = 10
+		}
+	}
+	if s.config.MaxPageSize > 0 && query.PageSize > s.config.MaxPageSize {
+		query.PageSize = s.config.MaxPageSize
+	}
+
+	start := time.Now()
+	result, err := s.driver.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	result.Page = query.Page
+	result.PageSize = query.PageSize
+	result.Driver = s.driver.Name()
+	result.Took = time.Since(start)
+	if result.Hits == nil {
+		result.Hits = []SearchHit{}
+	}
+	return result, nil
+}
+
+// IndexStatus 获取所有索引状态
+func (s *SearchService) IndexStatus(ctx context.Context) ([]SearchIndexStatus, error) {
+	statuses := make([]SearchIndexStatus, 0, len(searchIndices))
+	for _, index := range searchIndices {
+		status, err := s.driver.Status(ctx, index)
+		if err != nil {
+			return nil, fmt.Errorf("获取索引 %s 状态失败: %v", index.Name, err)
+		}
+		s.mu.RLock()
+		if last, ok := s.lastSync[index.Name]; ok {
+			lastSync := last
+			status.LastSyncAt = &lastSync
+		}
+		s.mu.RUnlock()
+		statuses = append(statuses, *status)
+	}
+	return statuses, nil
+}
+
+// Reindex 重建索引
+// 功能说明：
+// 1. 从数据库按主键分批读取全部未删除记录
+// 2. 搜索引擎驱动先写入新索引，完成后原子切换别名，重建期间搜索不受影响
+// 3. 重建完成后更新同步时间，后续增量同步从此时开始
+func (s *SearchService) Reindex(ctx context.Context, name string) (int64, error) {
+	index, ok := LookupSearchIndex(name)
+	if !ok {
+		return 0, I18n.NewError("search.unknown_index", "未知的搜索索引: :index", I18n.Params{"index": name})
+	}
+
+	startedAt := time.Now()
+	count, err := s.driver.RebuildIndex(ctx, index, s.tableLoader(index))
+	if err != nil {
+		return count, err
+	}
+
+	s.mu.Lock()
+	s.lastSync[index.Name] = startedAt
+	s.mu.Unlock()
+	return count, nil
+}
+
+// DropIndex 删除索引
+func (s *SearchService) DropIndex(ctx context.Context, name string) error {
+	index, ok := LookupSearchIndex(name)
+	if !ok {
+		return I18n.NewError("search.unknown_index", "未知的搜索索引: :index", I18n.Params{"index": name})
+	}
+	if err := s.driver.DropIndex(ctx, index); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.lastSync, index.Name)
+	s.mu.Unlock()
+	return nil
+}
+
+// Sync 增量同步索引
+// 功能说明：
+// 1. 同步 since 之后更新的记录
+// 2. since 之后被软删除的记录从索引中删除
+// 3. 数据库驱动直接查询数据表，无需同步
+func (s *SearchService) Sync(ctx context.Context, name string, since time.Time) (int64, error) {
+	index, ok := LookupSearchIndex(name)
+	if !ok {
+		return 0, I18n.NewError("search.unknown_index", "未知的搜索索引: :index", I18n.Params{"index": name})
+	}
+	if s.driver.Name() == "database" {
+		return 0, nil
+	}
+
+	db := s.getDB()
+	if db == nil {
+		return 0, fmt.Errorf("数据库连接不可用")
+	}
+	if !db.Migrator().HasTable(index.Table) {
+		return 0, nil
+	}
+
+	startedAt := time.Now()
+	var synced int64
+	var lastID uint64
+	batchSize := s.batchSize()
+
+	for {
+		var rows []map[string]interface{}
+		err := db.WithContext(ctx).Table(index.Table).
+			Select(append(append([]string{}, index.Columns...), "deleted_at")).
+			Where("(updated_at > ? OR deleted_at > ?) AND id > ?", since, since, lastID).
+			Order("id ASC").Limit(batchSize).
+			Find(&rows).Error
+		if err != nil {
+			return synced, fmt.Errorf("读取 %s 变更记录失败: %v", index.Table, err)
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		var upserts []map[string]interface{}
+		var deletes []string
+		for _, row := range rows {
+			lastID = toUint64(row["id"])
+			if row["deleted_at"] != nil {
+				deletes = append(deletes, fmt.Sprint(row["id"]))
+				continue
+			}
+			delete(row, "deleted_at")
+			upserts = append(upserts, row)
+		}
+
+		if len(upserts) > 0 {
+			if err := s.driver.IndexDocuments(ctx, index, upserts); err != nil {
+				return synced, err
+			}
+		}
+		if len(deletes) > 0 {
+			if err := s.driver.DeleteDocuments(ctx, index, deletes); err != nil {
+				return synced, err
+			}
+		}
+		synced += int64(len(rows))
+
+		if len(rows) < batchSize {
+			break
+		}
+	}
+
+	s.mu.Lock()
+	s.lastSync[index.Name] = startedAt
+	s.mu.Unlock()
+	return synced, nil
+}
+
+// syncLoop 后台增量同步
+func (s *SearchService) syncLoop() {
+	ticker := time.NewTicker(s.config.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			for _, index := range searchIndices {
+				s.mu.RLock()
+				since, ok := s.lastSync[index.Name]
+				s.mu.RUnlock()
+				if !ok {
+					// 尚未重建过的索引从一个同步周期之前开始，避免启动时全量扫描
+					since = time.Now().Add(-2 * s.config.SyncInterval)
+				}
+				if _, err := s.Sync(s.ctx, index.Name, since); err != nil {
+					log.Printf("搜索索引 %s 增量同步失败: %v", index.Name, err)
+				}
+			}
+		}
+	}
+}
+
+// tableLoader 创建从数据表分批读取文档的加载器
+func (s *SearchService) tableLoader(index SearchIndex) SearchDocumentLoader {
+	return func(ctx context.Context, handle func(documents []map[string]interface{}) error) error {
+		db := s.getDB()
+		if db == nil {
+			return fmt.Errorf("数据库连接不可用")
+		}
+		if !db.Migrator().HasTable(index.Table) {
+			return nil
+		}
+
+		var lastID uint64
+		batchSize := s.batchSize()
+		for {
+			var rows []map[string]interface{}
+			err := db.WithContext(ctx).Table(index.Table).
+				Select(index.Columns).
+				Where("deleted_at IS NULL AND id > ?", lastID).
+				Order("id ASC").Limit(batchSize).
+				Find(&rows).Error
+			if err != nil {
+				return fmt.Errorf("读取 %s 记录失败: %v", index.Table, err)
+			}
+			if len(rows) == 0 {
+				return nil
+			}
+
+			lastID = toUint64(rows[len(rows)-1]["id"])
+			if err := handle(rows); err != nil {
+				return err
+			}
+			if len(rows) < batchSize {
+				return nil
+			}
+		}
+	}
+}
+
+// batchSize 获取同步批次大小
+func (s *SearchService) batchSize() int {
+	if s.config.SyncBatchSize > 0 {
+		return s.config.SyncBatchSize
+	}
+	return 500
+}
+
+// HighlightText 生成高亮片段
+// 功能说明：
+// 1. 在文本中查找查询词（不区分大小写），用 <em></em> 包裹
+// 2. 片段以第一个命中位置为中心截取，最长约 fragmentSize 个字符
+// 3. 文本内容会先做HTML转义，防止注入
+// 4. 没有命中时返回空字符串
+func HighlightText(text string, terms []string, fragmentSize int) string {
+	if text == "" || len(terms) == 0 {
+		return ""
+	}
+	if fragmentSize <= 0 {
+		fragmentSize = 150
+	}
+
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+
+	type match struct{ start, end int }
+	var matches []match
+	for _, term := range terms {
+		needle := []rune(strings.ToLower(term))
+		if len(needle) == 0 {
+			continue
+		}
+		for i := 0; i+len(needle) <= len(lower); i++ {
+			if string(lower[i:i+len(needle)]) == string(needle) {
+				matches = append(matches, match{i, i + len(needle)})
+				i += len(needle) - 1
+			}
+		}
+	}
+	if len(matches) == 0 {
+		return ""
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+
+	// 以第一个命中为中心确定片段范围
+	begin := matches[0].start - fragmentSize/3
+	if begin < 0 {
+		begin = 0
+	}
+	end := begin + fragmentSize
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	var builder strings.Builder
+	if begin > 0 {
+		builder.WriteString("…")
+	}
+	cursor := begin
+	for _, m := range matches {
+		if m.start < cursor || m.end > end {
+			continue
+		}
+		builder.WriteString(html.EscapeString(string(runes[cursor:m.start])))
+		builder.WriteString("<em>")
+		builder.WriteString(html.EscapeString(string(runes[m.start:m.end])))
+		builder.WriteString("</em>")
+		cursor = m.end
+	}
+	builder.WriteString(html.EscapeString(string(runes[cursor:end])))
+	if end < len(runes) {
+		builder.WriteString("…")
+	}
+	return builder.String()
+}
+
+// scoreDocument 计算文档相关度（数据库驱动使用）
+//
+// 每个命中计1分，标题字段命中权重翻倍。
+func scoreDocument(index SearchIndex, document map[string]interface{}, terms []string) float64 {
+	var score float64
+	for _, field := range index.Fields {
+		value, ok := document[field]
+		if !ok || value == nil {
+			continue
+		}
+		text := strings.ToLower(fmt.Sprint(value))
+		weight := 1.0
+		if field == index.TitleField {
+			weight = 2.0
+		}
+		for _, term := range terms {
+			score += weight * float64(strings.Count(text, strings.ToLower(term)))
+		}
+	}
+	return score
+}
+
+// buildHighlights 为所有检索字段生成高亮片段
+func buildHighlights(index SearchIndex, document map[string]interface{}, terms []string) map[string][]string {
+	highlights := make(map[string][]string)
+	for _, field := range index.Fields {
+		value, ok := document[field]
+		if !ok || value == nil {
+			continue
+		}
+		text := fmt.Sprint(value)
+		size := 150
+		if utf8.RuneCountInString(text) < size {
+			size = utf8.RuneCountInString(text)
+		}
+		if fragment := HighlightText(text, terms, size); fragment != "" {
+			highlights[field] = []string{fragment}
+		}
+	}
+	return highlights
+}
+
+// containsString 检查字符串切片是否包含指定值
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+// toUint64 将数据库返回的主键转换为 uint64
+func toUint64(value interface{}) uint64 {
+	switch v := value.(type) {
+	case int64:
+		return uint64(v)
+	case int32:
+		return uint64(v)
+	case int:
+		return uint64(v)
+	case uint64:
+		return v
+	case uint32:
+		return uint64(v)
+	case uint:
+		return uint64(v)
+	case float64:
+		return uint64(v)
+	case []byte:
+		var id uint64
+		fmt.Sscan(string(v), &id)
+		return id
+	case string:
+		var id uint64
+		fmt.Sscan(v, &id)
+		return id
+	}
+	return 0
+}
+
+// toTime 将数据库返回的时间字段转换为 time.Time
+func toTime(value interface{}) *time.Time {
+	switch v := value.(type) {
+	case time.Time:
+		return &v
+	case *time.Time:
+		return v
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05"} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return &t
+			}
+		}
+	case []byte:
+		return toTime(string(v))
+	}
+	return nil
+}
@@ -0,0 +1,491 @@
+package Services
+
+import (
+	"bufio"
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	// ErrSecurityArchiveTableUnknown 表不支持安全数据归档
+	ErrSecurityArchiveTableUnknown = errors.New("不支持归档的安全数据表")
+	// ErrSecurityArchiveChecksum 归档文件与索引中的校验值不一致
+	ErrSecurityArchiveChecksum = errors.New("归档文件校验失败")
+)
+
+// 安全数据归档的默认参数
+const (
+	securityArchiveLegacyManifestKey = "manifest.json" // 旧版本保存在归档存储中的索引文件
+	defaultSecurityArchiveLimit      = 100
+	maxSecurityArchiveSearchLimit    = 1000
+)
+
+// securityArchiveTable 可归档的安全数据表
+type securityArchiveTable struct {
+	Name       string      // 表名
+	TimeColumn string      // 判断是否过期的时间列，也是归档文件中的JSON字段名
+	Model      interface{} // 模型指针
+}
+
+// securityArchiveTables 支持归档的安全数据表
+var securityArchiveTables = []securityArchiveTable{
+	{Name: "audit_logs", TimeColumn: "created_at", Model: &Models.AuditLog{}},
+	{Name: "security_events", TimeColumn: "created_at", Model: &Models.SecurityEvent{}},
+	{Name: "login_attempts", TimeColumn: "attempt_time", Model: &Models.LoginAttempt{}},
+}
+
+// SecurityArchiveFile 归档文件索引项
+type SecurityArchiveFile = Models.SecurityArchiveFile
+
+// SecurityArchiveManifest 归档索引，由数据库中的归档文件索引生成
+type SecurityArchiveManifest struct {
+	UpdatedAt time.Time             `json:"updated_at"`
+	Files     []SecurityArchiveFile `json:"files"`
+}
+
+// SecurityArchiveQuery 归档搜索条件
+type SecurityArchiveQuery struct {
+	Table   string            // 表名，为空时搜索全部表
+	From    time.Time         // 时间下界（包含），零值表示不限
+	To      time.Time         // 时间上界（不包含），零值表示不限
+	Filters map[string]string // 按字段相等过滤，字段名为归档记录的JSON字段名
+	Text    string            // 记录中包含的关键字，不区分大小写
+	Limit   int               // 最多返回的记录数，默认100，最多1000
+}
+
+// SecurityArchiveRecord 归档搜索结果中的一条记录
+type SecurityArchiveRecord struct {
+	Table  string                 `json:"table"`
+	File   string                 `json:"file"`
+	Record map[string]interface{} `json:"record"`
+}
+
+// SecurityArchiveSearchResult 归档搜索结果
+type SecurityArchiveSearchResult struct {
+	Records      []SecurityArchiveRecord `json:"records"`
+	FilesScanned int                     `json:"files_scanned"`
+	FilesMatched int                     `json:"files_matched"` // 时间范围重叠的文件数
+	Truncated    bool                    `json:"truncated"`     // 达到返回数量或文件数上限，还有未返回的记录
+}
+
+// SecurityArchiveService 安全数据归档服务
+// 功能说明：
+// 1. Archive 把超过保留时间的审计日志、安全事件和登录尝试按主键顺序导出为gzip压缩的JSON Lines文件，包含软删除的记录
+// 2. 文件写入归档存储并读回校验，写入数据库中的归档文件索引后才分批删除记录，任一步失败时记录保留在数据库中
+// 3. 同一批记录重新归档时文件键相同，覆盖原文件并替换索引项，不会产生重复的归档
+// 4. 每个文件一条索引记录，多个实例同时归档时不会覆盖其他实例写入的索引；旧版本的 manifest.json 在首次读取索引时导入
+// 5. Search 按索引选择时间范围重叠的文件，校验后按时间、字段和关键字过滤记录，用于合规调查
+type SecurityArchiveService struct {
+	db      *gorm.DB
+	config  *Config.SecurityArchiveConfig
+	store   ArchiveObjectStore
+	tables  []securityArchiveTable
+	schemas sync.Map
+	now     func() time.Time
+
+	// archiveMu 保证同一实例同一时间只有一次归档
+	archiveMu sync.Mutex
+
+	// legacyMu 保护旧版索引的导入状态
+	legacyMu       sync.Mutex
+	legacyImported bool
+}
+
+// NewSecurityArchiveService 创建安全数据归档服务，config 为 nil 时使用默认配置
+func NewSecurityArchiveService(db *gorm.DB, config *Config.SecurityArchiveConfig, store ArchiveObjectStore) *SecurityArchiveService {
+	if config == nil {
+		securityConfig := &Config.SecurityConfig{}
+		securityConfig.SetDefaults()
+		config = &securityConfig.Archive
+	}
+	tables := make([]securityArchiveTable, 0)
+	for _, name := range config.TableNames() {
+		for _, table := range securityArchiveTables {
+			if table.Name == name {
+				tables = append(tables, table)
+			}
+		}
+	}
+	return &SecurityArchiveService{
+		db:     db,
+		config: config,
+		store:  store,
+		tables: tables,
+		now:    time.Now,
+	}
+}
+
+// SetClock 设置计算保留时间使用的时钟
+func (s *SecurityArchiveService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// ExcludeTables 不再归档指定的表，用于跳过已由大表分区归档管理的表
+func (s *SecurityArchiveService) ExcludeTables(names ...string) {
+	tables := s.tables[:0]
+	for _, table := range s.tables {
+		excluded := false
+		for _, name := range names {
+			if table.Name == name {
+				excluded = true
+			}
+		}
+		if !excluded {
+			tables = append(tables, table)
+		}
+	}
+	s.tables = tables
+}
+
+// Tables 返回归档的表名
+func (s *SecurityArchiveService) Tables() []string {
+	names := make([]string, 0, len(s.tables))
+	for _, table := range s.tables {
+		names = append(names, table.Name)
+	}
+	return names
+}
+
+// Archive 归档并删除超过保留时间的记录，返回删除的记录数，由清理调度器定期运行
+// 单个表失败时继续归档其他表，返回第一个错误
+func (s *SecurityArchiveService) Archive(ctx context.Context) (int64, error) {
+	if s.store == nil {
+		return 0, errors.New("归档存储未配置")
+	}
+	s.archiveMu.Lock()
+	defer s.archiveMu.Unlock()
+
+	cutoff := s.now().Add(-s.config.Retention)
+	var deleted int64
+	var firstErr error
+	for _, table := range s.tables {
+		n, err := s.archiveTable(ctx, table, cutoff)
+		deleted += n
+		if err != nil {
+			if ctx.Err() != nil {
+				return deleted, err
+			}
+			log.Printf("归档安全数据表 %s 失败: %v", table.Name, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", table.Name, err)
+			}
+		}
+	}
+	return deleted, firstErr
+}
+
+// archiveTable 按 RowsPerFile 分批归档一个表中早于 cutoff 的记录
+func (s *SecurityArchiveService) archiveTable(ctx context.Context, table securityArchiveTable, cutoff time.Time) (int64, error) {
+	sch, err := schema.Parse(table.Model, &s.schemas, s.db.NamingStrategy)
+	if err != nil {
+		return 0, err
+	}
+	idField := sch.LookUpField("id")
+	timeField := sch.LookUpField(table.TimeColumn)
+	if idField == nil || timeField == nil {
+		return 0, fmt.Errorf("模型缺少主键或时间列: %s", table.TimeColumn)
+	}
+	sliceType := reflect.SliceOf(reflect.TypeOf(table.Model).Elem())
+
+	var deleted int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+		rows := reflect.New(sliceType)
+		err := s.db.WithContext(ctx).Unscoped().Model(table.Model).
+			Where(fmt.Sprintf("%s < ?", table.TimeColumn), cutoff).
+			Order("id").Limit(s.config.RowsPerFile).Find(rows.Interface()).Error
+		if err != nil {
+			return deleted, err
+		}
+		items := rows.Elem()
+		if items.Len() == 0 {
+			return deleted, nil
+		}
+
+		file, ids, err := s.writeFile(ctx, table, items, idField, timeField)
+		if err != nil {
+			return deleted, err
+		}
+		if err := s.indexFile(ctx, file); err != nil {
+			return deleted, err
+		}
+		n, err := BatchDelete(ctx, s.db.Unscoped().Where("id IN ?", ids), table.Model)
+		deleted += n
+		if err != nil {
+			return deleted, err
+		}
+		if items.Len() < s.config.RowsPerFile {
+			return deleted, nil
+		}
+	}
+}
+
+// writeFile 把一批记录编码为gzip压缩的JSON Lines文件写入归档存储，读回校验后返回索引项和记录主键
+func (s *SecurityArchiveService) writeFile(ctx context.Context, table securityArchiveTable, items reflect.Value, idField, timeField *schema.Field) (SecurityArchiveFile, []uint, error) {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	encoder := json.NewEncoder(writer)
+	file := SecurityArchiveFile{Table: table.Name, Rows: int64(items.Len())}
+	ids := make([]uint, 0, items.Len())
+	for i := 0; i < items.Len(); i++ {
+		item := items.Index(i)
+		if err := encoder.Encode(item.Interface()); err != nil {
+			return file, nil, err
+		}
+		rawID, _ := idField.ValueOf(ctx, item)
+		id, _ := rawID.(uint)
+		ids = append(ids, id)
+		rawTime, _ := timeField.ValueOf(ctx, item)
+		at, _ := rawTime.(time.Time)
+		if file.From.IsZero() || at.Before(file.From) {
+			file.From = at
+		}
+		if at.After(file.To) {
+			file.To = at
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return file, nil, err
+	}
+
+	data := buffer.Bytes()
+	sum := sha256.Sum256(data)
+	file.FirstID = ids[0]
+	file.LastID = ids[len(ids)-1]
+	file.Key = fmt.Sprintf("%s%s/%s/%d-%d.jsonl.gz", s.config.Prefix, table.Name, file.From.UTC().Format("2006-01"), file.FirstID, file.LastID)
+	file.Size = int64(len(data))
+	file.Checksum = hex.EncodeToString(sum[:])
+	file.ArchivedAt = s.now()
+
+	if err := s.store.Put(ctx, file.Key, data); err != nil {
+		return file, nil, err
+	}
+	stored, err := s.store.Get(ctx, file.Key)
+	if err != nil {
+		return file, nil, err
+	}
+	if storedSum := sha256.Sum256(stored); hex.EncodeToString(storedSum[:]) != file.Checksum {
+		return file, nil, fmt.Errorf("%w: %s", ErrSecurityArchiveChecksum, file.Key)
+	}
+	return file, ids, nil
+}
+
+// indexFile 把归档文件写入索引，同名文件替换原索引项
+func (s *SecurityArchiveService) indexFile(ctx context.Context, file SecurityArchiveFile) error {
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "object_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"table_name", "row_count", "first_id", "last_id", "range_start", "range_end", "size", "checksum", "archived_at"}),
+	}).Create(&file).Error
+}
+
+// Manifest 读取归档索引，尚未归档过时返回空索引
+func (s *SecurityArchiveService) Manifest(ctx context.Context) (*SecurityArchiveManifest, error) {
+	if s.store == nil {
+		return nil, errors.New("归档存储未配置")
+	}
+	if err := s.importLegacyManifest(ctx); err != nil {
+		return nil, err
+	}
+	manifest := &SecurityArchiveManifest{Files: []SecurityArchiveFile{}}
+	if err := s.db.WithContext(ctx).Order("id").Find(&manifest.Files).Error; err != nil {
+		return nil, err
+	}
+	for _, file := range manifest.Files {
+		if file.ArchivedAt.After(manifest.UpdatedAt) {
+			manifest.UpdatedAt = file.ArchivedAt
+		}
+	}
+	return manifest, nil
+}
+
+// importLegacyManifest 把旧版本写入归档存储的 manifest.json 导入数据库索引，每个实例成功导入一次
+// 已存在的对象键保留数据库中的记录，多个实例同时导入不会产生重复的索引项
+func (s *SecurityArchiveService) importLegacyManifest(ctx context.Context) error {
+	s.legacyMu.Lock()
+	defer s.legacyMu.Unlock()
+	if s.legacyImported {
+		return nil
+	}
+
+	data, err := s.store.Get(ctx, s.config.Prefix+securityArchiveLegacyManifestKey)
+	if errors.Is(err, ErrArchiveObjectNotFound) {
+		s.legacyImported = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var legacy SecurityArchiveManifest
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return fmt.Errorf("解析归档索引失败: %w", err)
+	}
+	if len(legacy.Files) > 0 {
+		err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "object_key"}},
+			DoNothing: true,
+		}).CreateInBatches(&legacy.Files, 100).Error
+		if err != nil {
+			return fmt.Errorf("导入归档索引失败: %w", err)
+		}
+	}
+	s.legacyImported = true
+	return nil
+}
+
+// Files 返回与时间范围重叠的归档文件，table 为空时返回全部表，按记录时间倒序
+func (s *SecurityArchiveService) Files(ctx context.Context, table string, from, to time.Time) ([]SecurityArchiveFile, error) {
+	if table != "" && lookupSecurityArchiveTable(table) == nil {
+		return nil, fmt.Errorf("%w: %s", ErrSecurityArchiveTableUnknown, table)
+	}
+	manifest, err := s.Manifest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]SecurityArchiveFile, 0)
+	for _, file := range manifest.Files {
+		if table != "" && file.Table != table {
+			continue
+		}
+		if (!from.IsZero() && file.To.Before(from)) || (!to.IsZero() && !file.From.Before(to)) {
+			continue
+		}
+		files = append(files, file)
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].To.After(files[j].To)
+	})
+	return files, nil
+}
+
+// Search 搜索归档文件中的记录，按文件的记录时间倒序读取，最多读取 MaxSearchFiles 个文件
+// 文件与索引中的校验值不一致时返回 ErrSecurityArchiveChecksum
+func (s *SecurityArchiveService) Search(ctx context.Context, query SecurityArchiveQuery) (*SecurityArchiveSearchResult, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultSecurityArchiveLimit
+	}
+	limit = min(limit, maxSecurityArchiveSearchLimit)
+
+	files, err := s.Files(ctx, query.Table, query.From, query.To)
+	if err != nil {
+		return nil, err
+	}
+	result := &SecurityArchiveSearchResult{Records: []SecurityArchiveRecord{}, FilesMatched: len(files)}
+	text := strings.ToLower(query.Text)
+	for _, file := range files {
+		if result.FilesScanned >= s.config.MaxSearchFiles || len(result.Records) >= limit {
+			result.Truncated = true
+			break
+		}
+		table := lookupSecurityArchiveTable(file.Table)
+		if table == nil {
+			continue
+		}
+		result.FilesScanned++
+		truncated, err := s.searchFile(ctx, *table, file, query, text, limit, result)
+		if err != nil {
+			return nil, err
+		}
+		if truncated {
+			result.Truncated = true
+			break
+		}
+	}
+	return result, nil
+}
+
+// searchFile 读取并校验一个归档文件，把符合条件的记录加入结果；达到返回数量上限时返回 true
+func (s *SecurityArchiveService) searchFile(ctx context.Context, table securityArchiveTable, file SecurityArchiveFile, query SecurityArchiveQuery, text string, limit int, result *SecurityArchiveSearchResult) (bool, error) {
+	data, err := s.store.Get(ctx, file.Key)
+	if err != nil {
+		return false, err
+	}
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != file.Checksum {
+		return false, fmt.Errorf("%w: %s", ErrSecurityArchiveChecksum, file.Key)
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return false, err
+	}
+	defer reader.Close()
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if text != "" && !strings.Contains(strings.ToLower(string(line)), text) {
+			continue
+		}
+		var record map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.UseNumber()
+		if err := decoder.Decode(&record); err != nil {
+			return false, fmt.Errorf("解析归档文件 %s 失败: %w", file.Key, err)
+		}
+		if !securityArchiveRecordMatches(record, table.TimeColumn, query) {
+			continue
+		}
+		if len(result.Records) >= limit {
+			return true, nil
+		}
+		result.Records = append(result.Records, SecurityArchiveRecord{Table: table.Name, File: file.Key, Record: record})
+	}
+	return false, scanner.Err()
+}
+
+// securityArchiveRecordMatches 按时间范围和字段相等过滤归档记录
+func securityArchiveRecordMatches(record map[string]interface{}, timeColumn string, query SecurityArchiveQuery) bool {
+	if !query.From.IsZero() || !query.To.IsZero() {
+		raw, _ := record[timeColumn].(string)
+		at, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return false
+		}
+		if (!query.From.IsZero() && at.Before(query.From)) || (!query.To.IsZero() && !at.Before(query.To)) {
+			return false
+		}
+	}
+	for field, want := range query.Filters {
+		value, ok := record[field]
+		if !ok || value == nil {
+			if want != "" {
+				return false
+			}
+			continue
+		}
+		if fmt.Sprint(value) != want {
+			return false
+		}
+	}
+	return true
+}
+
+// lookupSecurityArchiveTable 返回支持归档的表，搜索时不要求表仍在 Tables 配置中
+func lookupSecurityArchiveTable(name string) *securityArchiveTable {
+	for i := range securityArchiveTables {
+		if securityArchiveTables[i].Name == name {
+			return &securityArchiveTables[i]
+		}
+	}
+	return nil
+}
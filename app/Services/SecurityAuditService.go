@@ -1,11 +1,15 @@
 package Services
 
 import (
+	"cloud-platform-api/app/Config"
 	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Models"
 	"cloud-platform-api/app/Storage"
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -18,7 +22,7 @@ import (
 // 功能说明：
 // 1. API密钥管理和验证
 // 2. 安全事件审计日志
-// 3. 安全扫描和漏洞检测
+// 3. 安全扫描和漏洞检测，扫描项由注册的 VulnerabilityScanner 插件提供
 // 4. 访问控制和安全策略
 // 5. 安全报告生成
 type SecurityAuditService struct {
@@ -26,8 +30,13 @@ type SecurityAuditService struct {
 	apiKeys        map[string]*APIKey
 	mutex          sync.RWMutex
 	config         *SecurityAuditConfig
+	scanners       []VulnerabilityScanner
+	scanMutex      sync.Mutex
 }
 
+// ErrSecurityScanInProgress 已有安全扫描正在执行
+var ErrSecurityScanInProgress = errors.New("安全扫描正在执行")
+
 // SecurityAuditConfig 安全审计配置
 type SecurityAuditConfig struct {
 	EnableAPIKeyAuth     bool          `json:"enable_api_key_auth"`     // 启用API密钥认证
@@ -35,6 +44,7 @@ type SecurityAuditConfig struct {
 	MaxAPIKeysPerUser    int           `json:"max_api_keys_per_user"`   // 每个用户最大API密钥数
 	EnableSecurityScan   bool          `json:"enable_security_scan"`    // 启用安全扫描
 	ScanInterval         time.Duration `json:"scan_interval"`           // 扫描间隔
+	ScanTimeout          time.Duration `json:"scan_timeout"`            // 单个扫描插件的超时时间
 	EnableAuditLog       bool          `json:"enable_audit_log"`        // 启用审计日志
 	AuditLogRetention    time.Duration `json:"audit_log_retention"`     // 审计日志保留时间
 	EnableRateLimit      bool          `json:"enable_rate_limit"`       // 启用速率限制
@@ -70,25 +80,10 @@ type SecurityEvent struct {
 }
 
 // SecurityScanResult 安全扫描结果
-type SecurityScanResult struct {
-	ID          string                 `json:"id" gorm:"primaryKey"`
-	ScanType    string                 `json:"scan_type" gorm:"size:50"`
-	Target      string                 `json:"target" gorm:"size:200"`
-	Vulnerabilities []Vulnerability    `json:"vulnerabilities" gorm:"serializer:json"`
-	RiskScore   int                    `json:"risk_score"`
-	Status      string                 `json:"status" gorm:"size:20"`
-	ScanTime    time.Time              `json:"scan_time"`
-	CreatedAt   time.Time              `json:"created_at"`
-}
+type SecurityScanResult = Models.SecurityScanResult
 
 // Vulnerability 漏洞信息
-type Vulnerability struct {
-	Type        string `json:"type"`
-	Severity    string `json:"severity"`
-	Description string `json:"description"`
-	Location    string `json:"location"`
-	Recommendation string `json:"recommendation"`
-}
+type Vulnerability = Models.Vulnerability
 
 // NewSecurityAuditService 创建安全审计服务
 // 功能说明：
@@ -97,12 +92,19 @@ type Vulnerability struct {
 // 3. 启动安全监控
 // 4. 定期安全扫描
 func NewSecurityAuditService(storageManager *Storage.StorageManager) *SecurityAuditService {
+	securityConfig := &Config.SecurityConfig{}
+	securityConfig.SetDefaults()
+	if globalConfig := Config.GetConfig(); globalConfig != nil {
+		securityConfig = &globalConfig.Security
+	}
+
 	config := &SecurityAuditConfig{
 		EnableAPIKeyAuth:     true,
 		APIKeyExpireTime:     365 * 24 * time.Hour, // 1年
 		MaxAPIKeysPerUser:    10,
-		EnableSecurityScan:   true,
-		ScanInterval:         24 * time.Hour, // 24小时
+		EnableSecurityScan:   securityConfig.Scan.Enabled,
+		ScanInterval:         securityConfig.Scan.Interval,
+		ScanTimeout:          securityConfig.Scan.Timeout,
 		EnableAuditLog:       true,
 		AuditLogRetention:    90 * 24 * time.Hour, // 90天
 		EnableRateLimit:      true,
@@ -120,11 +122,6 @@ func NewSecurityAuditService(storageManager *Storage.StorageManager) *SecurityAu
 		go service.startSecurityScanning()
 	}
 
-	// 启动审计日志清理
-	if config.EnableAuditLog {
-		go service.startAuditLogCleanup()
-	}
-
 	return service
 }
 
@@ -496,16 +493,46 @@ func (sas *SecurityAuditService) triggerSecurityAlert(event *SecurityEvent) {
 	})
 }
 
+// RegisterScanner 注册漏洞扫描插件
+func (sas *SecurityAuditService) RegisterScanner(scanner VulnerabilityScanner) {
+	sas.mutex.Lock()
+	defer sas.mutex.Unlock()
+	sas.scanners = append(sas.scanners, scanner)
+}
+
+// SetScanners 替换全部漏洞扫描插件
+func (sas *SecurityAuditService) SetScanners(scanners []VulnerabilityScanner) {
+	sas.mutex.Lock()
+	defer sas.mutex.Unlock()
+	sas.scanners = append([]VulnerabilityScanner(nil), scanners...)
+}
+
+// Scanners 返回已注册的漏洞扫描插件
+func (sas *SecurityAuditService) Scanners() []VulnerabilityScanner {
+	sas.mutex.RLock()
+	defer sas.mutex.RUnlock()
+	return append([]VulnerabilityScanner(nil), sas.scanners...)
+}
+
 // PerformSecurityScan 执行安全扫描
-// 功能说明：
-// 1. 扫描系统安全漏洞
-// 2. 检查配置安全性
-// 3. 生成扫描报告
-// 4. 提供修复建议
 func (sas *SecurityAuditService) PerformSecurityScan() (*SecurityScanResult, error) {
+	return sas.PerformSecurityScanContext(context.Background())
+}
+
+// PerformSecurityScanContext 执行安全扫描
+// 功能说明：
+// 1. 依次执行所有注册的扫描插件，每个插件单独设置超时
+// 2. 插件失败不影响其他插件，错误信息按插件名称记录在扫描结果中
+// 3. 根据漏洞严重程度计算风险评分并保存扫描结果
+// 4. 同一时间只执行一次扫描，正在扫描时返回 ErrSecurityScanInProgress
+func (sas *SecurityAuditService) PerformSecurityScanContext(ctx context.Context) (*SecurityScanResult, error) {
 	if !sas.config.EnableSecurityScan {
 		return nil, fmt.Errorf("安全扫描功能已禁用")
 	}
+	if !sas.scanMutex.TryLock() {
+		return nil, ErrSecurityScanInProgress
+	}
+	defer sas.scanMutex.Unlock()
 
 	result := &SecurityScanResult{
 		ID:       uuid.New().String(),
@@ -513,25 +540,25 @@ func (sas *SecurityAuditService) PerformSecurityScan() (*SecurityScanResult, err
 		Target:   "system",
 		Status:   "completed",
 		ScanTime: time.Now(),
-		CreatedAt: time.Now(),
 	}
 
-	// 执行各种安全检查
 	vulnerabilities := []Vulnerability{}
-
-	// 检查数据库连接安全
-	if err := sas.checkDatabaseSecurity(&vulnerabilities); err != nil {
-		log.Printf("数据库安全检查失败: %v", err)
-	}
-
-	// 检查API密钥安全
-	if err := sas.checkAPIKeySecurity(&vulnerabilities); err != nil {
-		log.Printf("API密钥安全检查失败: %v", err)
+	for _, scanner := range sas.Scanners() {
+		found, err := sas.runScanner(ctx, scanner)
+		if err != nil {
+			if result.Errors == nil {
+				result.Errors = make(map[string]string)
+			}
+			result.Errors[scanner.Name()] = err.Error()
+			log.Printf("安全扫描插件 %s 执行失败: %v", scanner.Name(), err)
+		}
+		for _, vuln := range found {
+			vuln.Scanner = scanner.Name()
+			vulnerabilities = append(vulnerabilities, vuln)
+		}
 	}
-
-	// 检查配置安全
-	if err := sas.checkConfigurationSecurity(&vulnerabilities); err != nil {
-		log.Printf("配置安全检查失败: %v", err)
+	if ctx.Err() != nil {
+		result.Status = "cancelled"
 	}
 
 	result.Vulnerabilities = vulnerabilities
@@ -539,74 +566,57 @@ func (sas *SecurityAuditService) PerformSecurityScan() (*SecurityScanResult, err
 
 	// 保存扫描结果
 	if err := Database.DB.Create(result).Error; err != nil {
-		log.Printf("保存安全扫描结果失败: %v", err)
+		return nil, fmt.Errorf("保存安全扫描结果失败: %v", err)
 	}
 
-	// 记录扫描完成事件
-	sas.logSecurityEvent(&SecurityEvent{
-		ID:        uuid.New().String(),
-		EventType: "security_scan_completed",
-		Resource:  "system",
-		Action:    "scan",
-		Status:    "success",
-		Details: map[string]interface{}{
-			"scan_id":     result.ID,
+	if sas.storageManager != nil {
+		sas.storageManager.LogInfo("安全扫描完成", map[string]interface{}{
+			"scan_id":               result.ID,
+			"status":                result.Status,
 			"vulnerabilities_count": len(vulnerabilities),
-			"risk_score":  result.RiskScore,
-		},
-		RiskLevel: "low",
-		CreatedAt: time.Now(),
-	})
+			"failed_scanners":       len(result.Errors),
+			"risk_score":            result.RiskScore,
+		})
+	}
 
 	return result, nil
 }
 
-// checkDatabaseSecurity 检查数据库安全
-func (sas *SecurityAuditService) checkDatabaseSecurity(vulnerabilities *[]Vulnerability) error {
-	// 检查数据库连接是否使用SSL
-	// 这里简化处理，实际应该检查具体的数据库配置
-	*vulnerabilities = append(*vulnerabilities, Vulnerability{
-		Type:        "database_security",
-		Severity:    "medium",
-		Description: "建议启用数据库SSL连接",
-		Location:    "database_config",
-		Recommendation: "在数据库配置中启用SSL连接",
-	})
-	return nil
+// runScanner 在超时时间内执行单个扫描插件，插件panic时作为错误返回
+func (sas *SecurityAuditService) runScanner(ctx context.Context, scanner VulnerabilityScanner) (found []Vulnerability, err error) {
+	if sas.config.ScanTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, sas.config.ScanTimeout)
+		defer cancel()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			found, err = nil, fmt.Errorf("扫描插件异常: %v", r)
+		}
+	}()
+	return scanner.Scan(ctx)
 }
 
-// checkAPIKeySecurity 检查API密钥安全
-func (sas *SecurityAuditService) checkAPIKeySecurity(vulnerabilities *[]Vulnerability) error {
-	// 检查过期的API密钥
-	var expiredKeys []APIKey
-	if err := Database.DB.Where("expires_at < ? AND is_active = ?", time.Now(), true).Find(&expiredKeys).Error; err != nil {
-		return err
+// GetScanResults 分页获取安全扫描结果，按扫描时间倒序
+func (sas *SecurityAuditService) GetScanResults(page, pageSize int) ([]SecurityScanResult, int64, error) {
+	var total int64
+	if err := Database.DB.Model(&SecurityScanResult{}).Count(&total).Error; err != nil {
+		return nil, 0, err
 	}
-
-	if len(expiredKeys) > 0 {
-		*vulnerabilities = append(*vulnerabilities, Vulnerability{
-			Type:        "api_key_security",
-			Severity:    "medium",
-			Description: fmt.Sprintf("发现 %d 个过期的API密钥", len(expiredKeys)),
-			Location:    "api_keys",
-			Recommendation: "清理过期的API密钥",
-		})
+	var results []SecurityScanResult
+	if err := Database.DB.Order("scan_time desc").Offset((page - 1) * pageSize).Limit(pageSize).Find(&results).Error; err != nil {
+		return nil, 0, err
 	}
-
-	return nil
+	return results, total, nil
 }
 
-// checkConfigurationSecurity 检查配置安全
-func (sas *SecurityAuditService) checkConfigurationSecurity(vulnerabilities *[]Vulnerability) error {
-	// 检查默认配置
-	*vulnerabilities = append(*vulnerabilities, Vulnerability{
-		Type:        "configuration_security",
-		Severity:    "low",
-		Description: "建议定期更新安全配置",
-		Location:    "app_config",
-		Recommendation: "定期审查和更新安全配置参数",
-	})
-	return nil
+// GetScanResult 获取指定的安全扫描结果
+func (sas *SecurityAuditService) GetScanResult(id string) (*SecurityScanResult, error) {
+	var result SecurityScanResult
+	if err := Database.DB.Where("id = ?", id).First(&result).Error; err != nil {
+		return nil, err
+	}
+	return &result, nil
 }
 
 // calculateRiskScore 计算风险评分
@@ -639,24 +649,14 @@ func (sas *SecurityAuditService) startSecurityScanning() {
 	}
 }
 
-// startAuditLogCleanup 启动审计日志清理
-func (sas *SecurityAuditService) startAuditLogCleanup() {
-	ticker := time.NewTicker(24 * time.Hour)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		cutoffTime := time.Now().Add(-sas.config.AuditLogRetention)
-		
-		// 清理过期的安全事件
-		if err := Database.DB.Where("created_at < ?", cutoffTime).Delete(&SecurityEvent{}).Error; err != nil {
-			log.Printf("清理审计日志失败: %v", err)
-		}
-
-		// 清理过期的扫描结果
-		if err := Database.DB.Where("created_at < ?", cutoffTime).Delete(&SecurityScanResult{}).Error; err != nil {
-			log.Printf("清理扫描结果失败: %v", err)
-		}
+// CleanupScanResults 分批删除超过审计日志保留期的扫描结果，由清理调度器定期运行
+// 安全事件由表归档服务按分区归档，这里只清理过期的扫描结果
+func (sas *SecurityAuditService) CleanupScanResults(ctx context.Context) (int64, error) {
+	if !sas.config.EnableAuditLog || sas.config.AuditLogRetention <= 0 || Database.DB == nil {
+		return 0, nil
 	}
+	cutoffTime := time.Now().Add(-sas.config.AuditLogRetention)
+	return BatchDelete(ctx, Database.DB.Where("created_at < ?", cutoffTime), &SecurityScanResult{})
 }
 
 // GetSecurityReport 获取安全报告
@@ -685,7 +685,7 @@ func (sas *SecurityAuditService) GetSecurityReport() map[string]interface{} {
 
 	// 获取最近的扫描结果
 	var lastScan SecurityScanResult
-	Database.DB.Order("created_at desc").First(&lastScan)
+	Database.DB.Order("scan_time desc").First(&lastScan)
 
 	report := map[string]interface{}{
 		"generated_at":     time.Now(),
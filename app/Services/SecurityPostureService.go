@@ -0,0 +1,418 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 安全态势评分分类
+const (
+	PostureCategoryMFA             = "mfa"
+	PostureCategoryAPIKeys         = "api_keys"
+	PostureCategoryVulnerabilities = "vulnerabilities"
+	PostureCategoryPasswordPolicy  = "password_policy"
+	PostureCategoryHighRiskEvents  = "high_risk_events"
+)
+
+// 评分趋势方向
+const (
+	PostureTrendUp   = "up"
+	PostureTrendDown = "down"
+	PostureTrendFlat = "flat"
+)
+
+// PostureCategory 安全态势评分的一项
+type PostureCategory struct {
+	Key            string                 `json:"key"`
+	Name           string                 `json:"name"`
+	Score          float64                `json:"score"`     // 0-100
+	Weight         int                    `json:"weight"`    // 权重
+	Available      bool                   `json:"available"` // 没有数据时为false，不参与总分计算
+	Metrics        map[string]interface{} `json:"metrics"`   // 计算分数使用的统计值
+	Recommendation string                 `json:"recommendation,omitempty"`
+}
+
+// PostureTrend 与上一次评分快照相比的变化
+type PostureTrend struct {
+	PreviousScore float64   `json:"previous_score"`
+	PreviousAt    time.Time `json:"previous_at"`
+	Change        float64   `json:"change"`
+	Direction     string    `json:"direction"` // up、down、flat
+}
+
+// SecurityPosture 安全态势评分
+type SecurityPosture struct {
+	Score       float64                          `json:"score"` // 0-100，可用分类按权重加权平均
+	Grade       string                           `json:"grade"` // A、B、C、D、F，没有可用数据时为空
+	Categories  []PostureCategory                `json:"categories"`
+	Trend       *PostureTrend                    `json:"trend,omitempty"`   // 没有历史快照时为空
+	History     []Models.SecurityPostureSnapshot `json:"history,omitempty"` // 评分快照，按时间升序
+	EvaluatedAt time.Time                        `json:"evaluated_at"`
+}
+
+// SecurityPostureService 安全态势评分服务
+// 功能说明：
+// 1. 汇总MFA普及率、闲置API密钥、扫描发现的严重漏洞、密码策略合规率、未处理的高风险事件，计算加权安全评分
+// 2. 每项分数为0-100，附带统计值和改进建议，供仪表盘展示分项明细
+// 3. 定期保存评分快照，返回当前评分时附带与上一次快照的变化和历史趋势
+//
+// 评分规则：
+// - MFA：正常用户中绑定手机号（可使用短信MFA）的比例
+// - API密钥：启用的密钥中未过期且在 StaleAPIKeyAge 内使用过（或创建不久）的比例，没有密钥时为满分
+// - 漏洞：最近一次安全扫描中每个严重漏洞扣25分，每个高危漏洞扣10分，从未扫描时不参与计算
+// - 密码策略：正常用户中不需要强制修改密码且密码未超过修改周期的比例
+// - 高风险事件：未解决的高危和严重告警，以及 HighRiskWindow 内未被阻止的高危和严重安全事件，每个扣10分
+type SecurityPostureService struct {
+	db             *gorm.DB
+	config         *Config.SecurityPostureConfig
+	passwordMaxAge time.Duration
+	now            func() time.Time
+}
+
+// NewSecurityPostureService 创建安全态势评分服务，config 为空时使用默认安全配置
+func NewSecurityPostureService(db *gorm.DB, config *Config.SecurityConfig) *SecurityPostureService {
+	if config == nil {
+		config = &Config.SecurityConfig{}
+		config.SetDefaults()
+	}
+	return &SecurityPostureService{
+		db:             db,
+		config:         &config.Posture,
+		passwordMaxAge: config.BaseSecurity.PasswordChangeInterval,
+		now:            time.Now,
+	}
+}
+
+// SetClock 设置时钟（用于测试）
+func (s *SecurityPostureService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// Evaluate 计算当前安全态势评分
+func (s *SecurityPostureService) Evaluate() (*SecurityPosture, error) {
+	now := s.now()
+	evaluators := []func(time.Time) (PostureCategory, error){
+		s.evaluateMFA,
+		s.evaluateAPIKeys,
+		s.evaluateVulnerabilities,
+		s.evaluatePasswordPolicy,
+		s.evaluateHighRiskEvents,
+	}
+
+	posture := &SecurityPosture{EvaluatedAt: now}
+	var weighted float64
+	var totalWeight int
+	for _, evaluate := range evaluators {
+		category, err := evaluate(now)
+		if err != nil {
+			return nil, err
+		}
+		category.Score = roundScore(category.Score)
+		if category.Available && category.Weight > 0 {
+			weighted += category.Score * float64(category.Weight)
+			totalWeight += category.Weight
+		}
+		posture.Categories = append(posture.Categories, category)
+	}
+	if totalWeight > 0 {
+		posture.Score = roundScore(weighted / float64(totalWeight))
+		posture.Grade = postureGrade(posture.Score)
+	}
+	return posture, nil
+}
+
+// Posture 计算当前评分，附带与最近一次快照的变化和最近 days 天的快照
+func (s *SecurityPostureService) Posture(days int) (*SecurityPosture, error) {
+	posture, err := s.Evaluate()
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.History(days)
+	if err != nil {
+		return nil, err
+	}
+	posture.History = history
+
+	var previous Models.SecurityPostureSnapshot
+	err = s.db.Order("created_at DESC").Order("id DESC").First(&previous).Error
+	if err == nil {
+		change := roundScore(posture.Score - previous.Score)
+		direction := PostureTrendFlat
+		if change > 0 {
+			direction = PostureTrendUp
+		} else if change < 0 {
+			direction = PostureTrendDown
+		}
+		posture.Trend = &PostureTrend{
+			PreviousScore: previous.Score,
+			PreviousAt:    previous.CreatedAt,
+			Change:        change,
+			Direction:     direction,
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return posture, nil
+}
+
+// RecordSnapshot 计算当前评分并保存快照，超过保留时间的快照由 Cleanup 清理
+func (s *SecurityPostureService) RecordSnapshot() (*Models.SecurityPostureSnapshot, error) {
+	posture, err := s.Evaluate()
+	if err != nil {
+		return nil, err
+	}
+	scores := make(map[string]float64)
+	for _, category := range posture.Categories {
+		if category.Available {
+			scores[category.Key] = category.Score
+		}
+	}
+	data, err := json.Marshal(scores)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Models.SecurityPostureSnapshot{
+		Score:          posture.Score,
+		Grade:          posture.Grade,
+		Categories:     string(data),
+		CategoryScores: scores,
+		CreatedAt:      posture.EvaluatedAt,
+	}
+	if err := s.db.Create(snapshot).Error; err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Cleanup 分批删除超过保留时间的评分快照，由清理调度器定期运行；HistoryRetention 为0时不清理
+func (s *SecurityPostureService) Cleanup(ctx context.Context) (int64, error) {
+	if s.config.HistoryRetention <= 0 {
+		return 0, nil
+	}
+	cutoff := s.now().Add(-s.config.HistoryRetention)
+	return BatchDelete(ctx, s.db.Where("created_at < ?", cutoff), &Models.SecurityPostureSnapshot{})
+}
+
+// History 返回最近 days 天的评分快照，按时间升序；days 默认30，最多365
+func (s *SecurityPostureService) History(days int) ([]Models.SecurityPostureSnapshot, error) {
+	if days <= 0 {
+		days = 30
+	}
+	if days > 365 {
+		days = 365
+	}
+	since := s.now().AddDate(0, 0, -days)
+	var snapshots []Models.SecurityPostureSnapshot
+	if err := s.db.Where("created_at >= ?", since).Order("created_at").Order("id").Find(&snapshots).Error; err != nil {
+		return nil, err
+	}
+	for i := range snapshots {
+		snapshots[i].CategoryScores = make(map[string]float64)
+		if err := json.Unmarshal([]byte(snapshots[i].Categories), &snapshots[i].CategoryScores); err != nil {
+			log.Printf("解析安全评分快照 %d 失败: %v", snapshots[i].ID, err)
+		}
+	}
+	return snapshots, nil
+}
+
+// Start 按 SnapshotInterval 定期保存评分快照，最近一个间隔内没有快照时立即保存一次
+func (s *SecurityPostureService) Start(ctx context.Context) {
+	if !s.config.Enabled || s.config.SnapshotInterval <= 0 {
+		return
+	}
+	go func() {
+		var latest Models.SecurityPostureSnapshot
+		err := s.db.Order("created_at DESC").First(&latest).Error
+		if err != nil || s.now().Sub(latest.CreatedAt) >= s.config.SnapshotInterval {
+			if _, err := s.RecordSnapshot(); err != nil {
+				log.Printf("保存安全评分快照失败: %v", err)
+			}
+		}
+
+		ticker := time.NewTicker(s.config.SnapshotInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.RecordSnapshot(); err != nil {
+					log.Printf("保存安全评分快照失败: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// evaluateMFA 正常用户中绑定手机号的比例
+func (s *SecurityPostureService) evaluateMFA(now time.Time) (PostureCategory, error) {
+	category := PostureCategory{Key: PostureCategoryMFA, Name: "MFA普及率", Weight: s.config.MFAWeight}
+	var total, enrolled int64
+	users := s.db.Model(&Models.User{}).Where("status = ?", 1)
+	if err := users.Count(&total).Error; err != nil {
+		return category, err
+	}
+	if err := s.db.Model(&Models.User{}).Where("status = ? AND phone <> ''", 1).Count(&enrolled).Error; err != nil {
+		return category, err
+	}
+	category.Metrics = map[string]interface{}{"active_users": total, "mfa_users": enrolled}
+	if total == 0 {
+		return category, nil
+	}
+	category.Available = true
+	category.Score = 100 * float64(enrolled) / float64(total)
+	if enrolled < total {
+		category.Recommendation = "提醒未绑定手机号的用户开启短信MFA"
+	}
+	return category, nil
+}
+
+// evaluateAPIKeys 启用的API密钥中未过期且近期使用过的比例
+func (s *SecurityPostureService) evaluateAPIKeys(now time.Time) (PostureCategory, error) {
+	category := PostureCategory{Key: PostureCategoryAPIKeys, Name: "API密钥", Weight: s.config.APIKeyWeight}
+	if !s.db.Migrator().HasTable(&Models.ApiKey{}) {
+		return category, nil
+	}
+	var total, expired, stale int64
+	if err := s.db.Model(&Models.ApiKey{}).Where("status = ?", 1).Count(&total).Error; err != nil {
+		return category, err
+	}
+	if err := s.db.Model(&Models.ApiKey{}).Where("status = ? AND expires_at IS NOT NULL AND expires_at < ?", 1, now).Count(&expired).Error; err != nil {
+		return category, err
+	}
+	staleBefore := now.Add(-s.config.StaleAPIKeyAge)
+	if err := s.db.Model(&Models.ApiKey{}).
+		Where("status = ? AND (expires_at IS NULL OR expires_at >= ?)", 1, now).
+		Where("(last_used_at IS NULL AND created_at < ?) OR last_used_at < ?", staleBefore, staleBefore).
+		Count(&stale).Error; err != nil {
+		return category, err
+	}
+	category.Available = true
+	category.Metrics = map[string]interface{}{"active_keys": total, "expired_keys": expired, "stale_keys": stale}
+	category.Score = 100
+	if total > 0 {
+		category.Score = 100 * (1 - float64(expired+stale)/float64(total))
+	}
+	if expired+stale > 0 {
+		category.Recommendation = "禁用已过期或长期未使用的API密钥"
+	}
+	return category, nil
+}
+
+// evaluateVulnerabilities 最近一次安全扫描发现的严重和高危漏洞
+func (s *SecurityPostureService) evaluateVulnerabilities(now time.Time) (PostureCategory, error) {
+	category := PostureCategory{Key: PostureCategoryVulnerabilities, Name: "漏洞", Weight: s.config.VulnWeight}
+	if !s.db.Migrator().HasTable(&SecurityScanResult{}) {
+		return category, nil
+	}
+	var scan SecurityScanResult
+	if err := s.db.Where("status = ?", "completed").Order("scan_time DESC").First(&scan).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			category.Recommendation = "执行安全扫描"
+			return category, nil
+		}
+		return category, err
+	}
+	var critical, high int
+	for _, vulnerability := range scan.Vulnerabilities {
+		switch vulnerability.Severity {
+		case "critical":
+			critical++
+		case "high":
+			high++
+		}
+	}
+	category.Available = true
+	category.Metrics = map[string]interface{}{"scan_id": scan.ID, "scan_time": scan.ScanTime, "critical": critical, "high": high}
+	category.Score = math.Max(0, 100-25*float64(critical)-10*float64(high))
+	if critical+high > 0 {
+		category.Recommendation = "修复最近一次安全扫描发现的严重和高危漏洞"
+	}
+	return category, nil
+}
+
+// evaluatePasswordPolicy 正常用户中密码符合修改周期的比例
+func (s *SecurityPostureService) evaluatePasswordPolicy(now time.Time) (PostureCategory, error) {
+	category := PostureCategory{Key: PostureCategoryPasswordPolicy, Name: "密码策略合规", Weight: s.config.PasswordWeight}
+	var total, compliant int64
+	if err := s.db.Model(&Models.User{}).Where("status = ?", 1).Count(&total).Error; err != nil {
+		return category, err
+	}
+	query := s.db.Model(&Models.User{}).Where("status = ? AND must_change_password = ?", 1, false)
+	if s.passwordMaxAge > 0 {
+		query = query.Where("COALESCE(password_changed_at, created_at) >= ?", now.Add(-s.passwordMaxAge))
+	}
+	if err := query.Count(&compliant).Error; err != nil {
+		return category, err
+	}
+	category.Metrics = map[string]interface{}{"active_users": total, "compliant_users": compliant}
+	if total == 0 {
+		return category, nil
+	}
+	category.Available = true
+	category.Score = 100 * float64(compliant) / float64(total)
+	if compliant < total {
+		category.Recommendation = "提醒密码已过期或需要修改密码的用户修改密码"
+	}
+	return category, nil
+}
+
+// evaluateHighRiskEvents 未解决的高风险告警和近期未阻止的高风险事件
+func (s *SecurityPostureService) evaluateHighRiskEvents(now time.Time) (PostureCategory, error) {
+	category := PostureCategory{Key: PostureCategoryHighRiskEvents, Name: "高风险事件", Weight: s.config.EventWeight}
+	hasAlerts := s.db.Migrator().HasTable(&Models.SecurityAlert{})
+	hasEvents := s.db.Migrator().HasTable(&Models.SecurityEvent{})
+	if !hasAlerts && !hasEvents {
+		return category, nil
+	}
+	highRisk := []string{"high", "critical"}
+	var alerts, events int64
+	if hasAlerts {
+		if err := s.db.Model(&Models.SecurityAlert{}).Where("resolved = ? AND severity IN ?", false, highRisk).Count(&alerts).Error; err != nil {
+			return category, err
+		}
+	}
+	if hasEvents {
+		if err := s.db.Model(&Models.SecurityEvent{}).
+			Where("blocked = ? AND event_level IN ? AND created_at >= ?", false, highRisk, now.Add(-s.config.HighRiskWindow)).
+			Count(&events).Error; err != nil {
+			return category, err
+		}
+	}
+	category.Available = true
+	category.Metrics = map[string]interface{}{"unresolved_alerts": alerts, "unblocked_events": events}
+	category.Score = math.Max(0, 100-10*float64(alerts+events))
+	if alerts+events > 0 {
+		category.Recommendation = "处理未解决的高风险告警和安全事件"
+	}
+	return category, nil
+}
+
+// postureGrade 按分数计算等级
+func postureGrade(score float64) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 80:
+		return "B"
+	case score >= 70:
+		return "C"
+	case score >= 60:
+		return "D"
+	default:
+		return "F"
+	}
+}
+
+// roundScore 分数保留一位小数
+func roundScore(score float64) float64 {
+	return math.Round(score*10) / 10
+}
@@ -0,0 +1,683 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 自动响应动作类型
+const (
+	ResponseActionBlockIP       = "block_ip"        // 封禁IP
+	ResponseActionForceLogout   = "force_logout"    // 强制下线（撤销用户已签发的token）
+	ResponseActionRequireMFA    = "require_mfa"     // 要求重新进行MFA验证
+	ResponseActionDisableAPIKey = "disable_api_key" // 禁用API密钥
+	ResponseActionNotify        = "notify"          // 发送通知
+)
+
+// AutoBlockPlaybookName 开启 AutoBlockOnAnomaly 且未配置剧本时使用的内置剧本名称
+const AutoBlockPlaybookName = "auto_block_on_anomaly"
+
+// ResponseLockoutType 自动响应封禁IP时写入的锁定类型
+const ResponseLockoutType = "ip_block"
+
+var (
+	// ErrUnknownResponseAction 未注册的响应动作
+	ErrUnknownResponseAction = errors.New("未注册的响应动作")
+	// ErrResponseExecutionNotPending 执行记录不是待审批状态
+	ErrResponseExecutionNotPending = errors.New("执行记录不是待审批状态")
+	// ErrResponseApprovalExpired 审批已超时
+	ErrResponseApprovalExpired = errors.New("审批已超时")
+)
+
+// ResponsePlaybook 响应剧本
+type ResponsePlaybook struct {
+	Name         string                   `json:"name"`
+	Description  string                   `json:"description,omitempty"`
+	Disabled     bool                     `json:"disabled,omitempty"`
+	EventTypes   []string                 `json:"event_types"`    // 触发的事件类型，为空或包含 "*" 时匹配所有事件
+	MinRiskScore float64                  `json:"min_risk_score"` // 事件风险评分达到该值才触发
+	DryRun       bool                     `json:"dry_run,omitempty"`
+	Actions      []ResponsePlaybookAction `json:"actions"`
+}
+
+// ResponsePlaybookAction 剧本中的动作
+//
+// 破坏性动作（封禁IP、强制下线、禁用API密钥）默认需要人工审批，AutoApprove 为 true 时直接执行；
+// 其他动作可以通过 RequireApproval 要求审批。
+type ResponsePlaybookAction struct {
+	Type            string            `json:"type"`
+	Params          map[string]string `json:"params,omitempty"`
+	AutoApprove     bool              `json:"auto_approve,omitempty"`
+	RequireApproval bool              `json:"require_approval,omitempty"`
+}
+
+// ResponseEvent 触发自动响应的安全事件
+type ResponseEvent struct {
+	ID            uint    `json:"id"`
+	EventType     string  `json:"event_type"`
+	UserID        uint    `json:"user_id"`
+	IPAddress     string  `json:"ip_address"`
+	RiskScore     float64 `json:"risk_score"`
+	Details       string  `json:"details"`
+	RequestID     string  `json:"request_id,omitempty"`
+	CorrelationID string  `json:"correlation_id,omitempty"`
+}
+
+// ResponseActionRequest 动作处理函数的输入
+type ResponseActionRequest struct {
+	Playbook string
+	Params   map[string]string
+	Event    ResponseEvent
+}
+
+// ResponseActionHandler 动作处理函数，返回执行结果说明
+type ResponseActionHandler func(ctx context.Context, request ResponseActionRequest) (string, error)
+
+// responseActionRegistration 已注册的动作
+type responseActionRegistration struct {
+	handler     ResponseActionHandler
+	destructive bool
+}
+
+// SecurityResponseService 安全事件自动响应服务
+// 功能说明：
+// 1. 安全事件记录后按事件类型和风险评分匹配剧本，依次处理剧本中的动作
+// 2. 内置封禁IP、强制下线、要求MFA重新验证、禁用API密钥、通知五种动作，可通过 RegisterActionHandler 扩展或替换
+// 3. 破坏性动作默认进入待审批状态，由管理员审批后执行，超过审批期限自动过期
+// 4. 演练模式（全局或剧本级）只记录将要执行的动作
+// 5. 每个动作的处理结果都写入执行记录，同一剧本对同一用户/IP在冷却时间内只触发一次
+type SecurityResponseService struct {
+	db        *gorm.DB
+	config    *Config.SecurityConfig
+	tokens    *TokenBlacklistService
+	channels  []NotificationChannel
+	playbooks []ResponsePlaybook
+	actions   map[string]responseActionRegistration
+	triggered map[string]time.Time // 剧本最近触发时间，key为 剧本|用户|IP
+	mu        sync.RWMutex
+}
+
+// NewSecurityResponseService 创建自动响应服务
+//
+// config 为 nil 时使用全局配置。
+func NewSecurityResponseService(db *gorm.DB, config *Config.SecurityConfig) *SecurityResponseService {
+	if config == nil {
+		if globalConfig := Config.GetConfig(); globalConfig != nil {
+			config = &globalConfig.Security
+		} else {
+			config = &Config.SecurityConfig{}
+			config.SetDefaults()
+		}
+	}
+
+	s := &SecurityResponseService{
+		db:        db,
+		config:    config,
+		tokens:    NewTokenBlacklistService(nil),
+		actions:   make(map[string]responseActionRegistration),
+		triggered: make(map[string]time.Time),
+	}
+	s.RegisterActionHandler(ResponseActionBlockIP, s.blockIP, true)
+	s.RegisterActionHandler(ResponseActionForceLogout, s.forceLogout, true)
+	s.RegisterActionHandler(ResponseActionDisableAPIKey, s.disableAPIKey, true)
+	s.RegisterActionHandler(ResponseActionRequireMFA, s.requireMFA, false)
+	s.RegisterActionHandler(ResponseActionNotify, s.notify, false)
+	return s
+}
+
+// Enabled 是否启用自动响应
+func (s *SecurityResponseService) Enabled() bool {
+	return s.config.Response.Enabled || s.config.AnomalyDetection.AutoBlockOnAnomaly
+}
+
+// RegisterActionHandler 注册动作处理函数，destructive 为 true 的动作默认需要审批
+func (s *SecurityResponseService) RegisterActionHandler(action string, handler ResponseActionHandler, destructive bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.actions[action] = responseActionRegistration{handler: handler, destructive: destructive}
+}
+
+// SetTokenBlacklistService 设置强制下线和MFA标记使用的黑名单服务（多实例部署时应使用Redis）
+func (s *SecurityResponseService) SetTokenBlacklistService(tokens *TokenBlacklistService) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = tokens
+}
+
+// AddNotificationChannel 添加通知动作使用的通知通道
+func (s *SecurityResponseService) AddNotificationChannel(channel NotificationChannel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.channels = append(s.channels, channel)
+}
+
+// LoadPlaybooks 从JSON文件加载剧本
+func (s *SecurityResponseService) LoadPlaybooks(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取剧本文件失败: %v", err)
+	}
+	var playbooks []ResponsePlaybook
+	if err := json.Unmarshal(data, &playbooks); err != nil {
+		return fmt.Errorf("解析剧本文件失败: %v", err)
+	}
+	return s.SetPlaybooks(playbooks)
+}
+
+// SetPlaybooks 替换剧本，动作类型必须已注册
+func (s *SecurityResponseService) SetPlaybooks(playbooks []ResponsePlaybook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make(map[string]bool)
+	for _, playbook := range playbooks {
+		if playbook.Name == "" {
+			return fmt.Errorf("剧本名称不能为空")
+		}
+		if names[playbook.Name] {
+			return fmt.Errorf("剧本名称重复: %s", playbook.Name)
+		}
+		names[playbook.Name] = true
+		if len(playbook.Actions) == 0 {
+			return fmt.Errorf("剧本 %s 没有配置动作", playbook.Name)
+		}
+		for _, action := range playbook.Actions {
+			if _, ok := s.actions[action.Type]; !ok {
+				return fmt.Errorf("剧本 %s: %w: %s", playbook.Name, ErrUnknownResponseAction, action.Type)
+			}
+		}
+	}
+
+	s.playbooks = playbooks
+	return nil
+}
+
+// Playbooks 获取生效的剧本
+func (s *SecurityResponseService) Playbooks() []ResponsePlaybook {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.playbooks) == 0 && s.config.AnomalyDetection.AutoBlockOnAnomaly {
+		return []ResponsePlaybook{{
+			Name:         AutoBlockPlaybookName,
+			Description:  "检测到异常行为时自动封禁来源IP",
+			EventTypes:   []string{"anomaly_detected"},
+			MinRiskScore: s.config.AnomalyDetection.AnomalyScoreThreshold,
+			Actions:      []ResponsePlaybookAction{{Type: ResponseActionBlockIP, AutoApprove: true}},
+		}}
+	}
+	return append([]ResponsePlaybook(nil), s.playbooks...)
+}
+
+// HandleEvent 按剧本处理安全事件，返回本次生成的执行记录
+func (s *SecurityResponseService) HandleEvent(ctx context.Context, event ResponseEvent) ([]Models.SecurityResponseExecution, error) {
+	if !s.Enabled() {
+		return nil, nil
+	}
+
+	var executions []Models.SecurityResponseExecution
+	for _, playbook := range s.Playbooks() {
+		if !playbook.matches(event) || !s.acquireCooldown(playbook.Name, event) {
+			continue
+		}
+
+		for _, action := range playbook.Actions {
+			execution, err := s.handleAction(ctx, playbook, action, event)
+			if err != nil {
+				return executions, err
+			}
+			executions = append(executions, *execution)
+		}
+	}
+	return executions, nil
+}
+
+// matches 剧本是否匹配事件
+func (p ResponsePlaybook) matches(event ResponseEvent) bool {
+	if p.Disabled || event.RiskScore < p.MinRiskScore {
+		return false
+	}
+	if len(p.EventTypes) == 0 {
+		return true
+	}
+	for _, eventType := range p.EventTypes {
+		if eventType == "*" || eventType == event.EventType {
+			return true
+		}
+	}
+	return false
+}
+
+// acquireCooldown 冷却时间内同一剧本对同一用户/IP只触发一次
+func (s *SecurityResponseService) acquireCooldown(playbook string, event ResponseEvent) bool {
+	cooldown := s.config.Response.Cooldown
+	if cooldown <= 0 {
+		return true
+	}
+
+	key := fmt.Sprintf("%s|%d|%s", playbook, event.UserID, event.IPAddress)
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if last, ok := s.triggered[key]; ok && now.Sub(last) < cooldown {
+		return false
+	}
+	s.triggered[key] = now
+
+	// 顺带清理过期的触发记录
+	for k, last := range s.triggered {
+		if now.Sub(last) >= cooldown {
+			delete(s.triggered, k)
+		}
+	}
+	return true
+}
+
+// handleAction 处理剧本中的一个动作并记录
+func (s *SecurityResponseService) handleAction(ctx context.Context, playbook ResponsePlaybook, action ResponsePlaybookAction, event ResponseEvent) (*Models.SecurityResponseExecution, error) {
+	params, _ := json.Marshal(action.Params)
+	execution := &Models.SecurityResponseExecution{
+		Playbook:  playbook.Name,
+		Action:    action.Type,
+		Params:    string(params),
+		EventType: event.EventType,
+		EventID:   event.ID,
+		IPAddress: event.IPAddress,
+		RiskScore: event.RiskScore,
+	}
+	if event.UserID != 0 {
+		userID := event.UserID
+		execution.UserID = &userID
+	}
+
+	s.mu.RLock()
+	registration := s.actions[action.Type]
+	s.mu.RUnlock()
+
+	switch {
+	case s.config.Response.DryRun || playbook.DryRun:
+		execution.Status = Models.ResponseStatusDryRun
+		execution.Result = describeResponseAction(action.Type, event)
+	case action.RequireApproval || (registration.destructive && !action.AutoApprove):
+		expiresAt := time.Now().Add(s.config.Response.ApprovalTimeout)
+		execution.Status = Models.ResponseStatusPendingApproval
+		execution.ExpiresAt = &expiresAt
+		execution.Result = describeResponseAction(action.Type, event)
+	default:
+		s.execute(ctx, execution, action.Params, event)
+	}
+
+	if err := s.db.Create(execution).Error; err != nil {
+		return nil, fmt.Errorf("保存响应执行记录失败: %v", err)
+	}
+	if execution.Status == Models.ResponseStatusFailed {
+		log.Printf("自动响应动作执行失败: playbook=%s action=%s error=%s", playbook.Name, action.Type, execution.Error)
+	}
+	return execution, nil
+}
+
+// execute 执行动作并将结果写入执行记录
+func (s *SecurityResponseService) execute(ctx context.Context, execution *Models.SecurityResponseExecution, params map[string]string, event ResponseEvent) {
+	s.mu.RLock()
+	registration, ok := s.actions[execution.Action]
+	s.mu.RUnlock()
+
+	now := time.Now()
+	execution.ExecutedAt = &now
+	if !ok {
+		execution.Status = Models.ResponseStatusFailed
+		execution.Error = fmt.Sprintf("%v: %s", ErrUnknownResponseAction, execution.Action)
+		return
+	}
+
+	result, err := registration.handler(ctx, ResponseActionRequest{
+		Playbook: execution.Playbook,
+		Params:   params,
+		Event:    event,
+	})
+	execution.Result = result
+	if err != nil {
+		execution.Status = Models.ResponseStatusFailed
+		execution.Error = err.Error()
+		return
+	}
+	execution.Status = Models.ResponseStatusExecuted
+	execution.Error = ""
+}
+
+// Approve 审批通过并执行动作
+func (s *SecurityResponseService) Approve(ctx context.Context, id, reviewerID uint) (*Models.SecurityResponseExecution, error) {
+	execution, err := s.pendingExecution(id)
+	if err != nil {
+		return execution, err
+	}
+
+	var params map[string]string
+	if execution.Params != "" {
+		json.Unmarshal([]byte(execution.Params), &params)
+	}
+	// 审批在另一个请求中进行，通知携带审批请求的ID
+	event := ResponseEvent{
+		ID:            execution.EventID,
+		EventType:     execution.EventType,
+		IPAddress:     execution.IPAddress,
+		RiskScore:     execution.RiskScore,
+		RequestID:     Utils.RequestIDFromContext(ctx),
+		CorrelationID: Utils.CorrelationIDFromContext(ctx),
+	}
+	if execution.UserID != nil {
+		event.UserID = *execution.UserID
+	}
+
+	if err := s.claimPending(execution, Models.ResponseStatusApproved, reviewerID); err != nil {
+		return execution, err
+	}
+	s.execute(ctx, execution, params, event)
+	if err := s.db.Save(execution).Error; err != nil {
+		return nil, fmt.Errorf("保存响应执行记录失败: %v", err)
+	}
+	return execution, nil
+}
+
+// Reject 拒绝待审批的动作
+func (s *SecurityResponseService) Reject(id, reviewerID uint, reason string) (*Models.SecurityResponseExecution, error) {
+	execution, err := s.pendingExecution(id)
+	if err != nil {
+		return execution, err
+	}
+
+	if err := s.claimPending(execution, Models.ResponseStatusRejected, reviewerID); err != nil {
+		return execution, err
+	}
+	if reason != "" {
+		execution.Error = reason
+		if err := s.db.Model(execution).Update("error", reason).Error; err != nil {
+			return nil, fmt.Errorf("保存响应执行记录失败: %v", err)
+		}
+	}
+	return execution, nil
+}
+
+// claimPending 以条件更新将待审批记录改为新状态，避免同一记录被重复审批
+func (s *SecurityResponseService) claimPending(execution *Models.SecurityResponseExecution, status string, reviewerID uint) error {
+	now := time.Now()
+	result := s.db.Model(&Models.SecurityResponseExecution{}).
+		Where("id = ? AND status = ?", execution.ID, Models.ResponseStatusPendingApproval).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"reviewed_by": reviewerID,
+			"reviewed_at": now,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("保存响应执行记录失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrResponseExecutionNotPending
+	}
+	execution.Status = status
+	execution.ReviewedBy = &reviewerID
+	execution.ReviewedAt = &now
+	return nil
+}
+
+// pendingExecution 获取待审批的执行记录，已超时的标记为过期
+func (s *SecurityResponseService) pendingExecution(id uint) (*Models.SecurityResponseExecution, error) {
+	var execution Models.SecurityResponseExecution
+	if err := s.db.First(&execution, id).Error; err != nil {
+		return nil, err
+	}
+	if execution.Status != Models.ResponseStatusPendingApproval {
+		return &execution, ErrResponseExecutionNotPending
+	}
+	if execution.ExpiresAt != nil && time.Now().After(*execution.ExpiresAt) {
+		execution.Status = Models.ResponseStatusExpired
+		s.db.Model(&execution).Update("status", Models.ResponseStatusExpired)
+		return &execution, ErrResponseApprovalExpired
+	}
+	return &execution, nil
+}
+
+// ExpirePendingApprovals 将超过审批期限的记录标记为过期
+func (s *SecurityResponseService) ExpirePendingApprovals() (int64, error) {
+	result := s.db.Model(&Models.SecurityResponseExecution{}).
+		Where("status = ? AND expires_at < ?", Models.ResponseStatusPendingApproval, time.Now()).
+		Update("status", Models.ResponseStatusExpired)
+	return result.RowsAffected, result.Error
+}
+
+// ListExecutions 分页查询执行记录，status 为空时查询全部
+func (s *SecurityResponseService) ListExecutions(status string, page, limit int) ([]Models.SecurityResponseExecution, int64, error) {
+	query, err := s.executionQuery(status)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var executions []Models.SecurityResponseExecution
+	err = query.Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&executions).Error
+	return executions, total, err
+}
+
+// ListExecutionsPage 按分页请求查询执行记录，支持页码分页和游标分页
+func (s *SecurityResponseService) ListExecutionsPage(status string, req Utils.PageRequest) ([]Models.SecurityResponseExecution, Utils.PageMeta, error) {
+	query, err := s.executionQuery(status)
+	if err != nil {
+		return nil, Utils.PageMeta{}, err
+	}
+	var executions []Models.SecurityResponseExecution
+	meta, err := Utils.Paginate(query, req, Utils.PageOrder{}, &executions)
+	return executions, meta, err
+}
+
+// executionQuery 先将超时的待审批记录置为过期，再按状态构建查询
+func (s *SecurityResponseService) executionQuery(status string) (*gorm.DB, error) {
+	if _, err := s.ExpirePendingApprovals(); err != nil {
+		return nil, err
+	}
+
+	query := s.db.Model(&Models.SecurityResponseExecution{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	return query, nil
+}
+
+// describeResponseAction 演练和待审批时记录的动作说明
+func describeResponseAction(action string, event ResponseEvent) string {
+	switch action {
+	case ResponseActionBlockIP:
+		return fmt.Sprintf("封禁IP %s", event.IPAddress)
+	case ResponseActionForceLogout:
+		return fmt.Sprintf("强制用户 %d 下线", event.UserID)
+	case ResponseActionRequireMFA:
+		return fmt.Sprintf("要求用户 %d 重新进行MFA验证", event.UserID)
+	case ResponseActionDisableAPIKey:
+		return fmt.Sprintf("禁用用户 %d 的API密钥", event.UserID)
+	case ResponseActionNotify:
+		return fmt.Sprintf("发送 %s 事件通知", event.EventType)
+	}
+	return action
+}
+
+// responseDuration 读取动作参数中的时长
+func responseDuration(params map[string]string, key string, fallback time.Duration) (time.Duration, error) {
+	value := params[key]
+	if value == "" {
+		return fallback, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("无效的 %s 参数: %s", key, value)
+	}
+	return duration, nil
+}
+
+// tokenLifetime token的最长有效期，强制下线标记至少保留这么久
+func tokenLifetime() time.Duration {
+	if config := Config.GetConfig(); config != nil && config.JWT.ExpireTime > 0 {
+		return time.Duration(config.JWT.ExpireTime) * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// blockIP 封禁IP：写入IP锁定记录，登录检查和安全中间件据此拒绝请求
+func (s *SecurityResponseService) blockIP(ctx context.Context, request ResponseActionRequest) (string, error) {
+	if request.Event.IPAddress == "" {
+		return "", fmt.Errorf("事件缺少IP地址")
+	}
+	duration, err := responseDuration(request.Params, "duration", s.config.Response.BlockDuration)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	lockout := Models.AccountLockout{
+		UserID:      request.Event.UserID,
+		IPAddress:   request.Event.IPAddress,
+		LockoutType: ResponseLockoutType,
+		Reason:      fmt.Sprintf("自动响应剧本 %s: %s", request.Playbook, request.Event.EventType),
+		LockoutTime: now,
+		ExpiryTime:  now.Add(duration),
+		Active:      true,
+	}
+	if err := s.db.WithContext(ctx).Create(&lockout).Error; err != nil {
+		return "", fmt.Errorf("创建IP封禁记录失败: %v", err)
+	}
+	InvalidateIPBlockCache()
+	return fmt.Sprintf("已封禁IP %s %s", request.Event.IPAddress, duration), nil
+}
+
+// forceLogout 强制下线：撤销用户此前签发的所有token
+func (s *SecurityResponseService) forceLogout(ctx context.Context, request ResponseActionRequest) (string, error) {
+	if request.Event.UserID == 0 {
+		return "", fmt.Errorf("事件缺少用户ID")
+	}
+	ttl, err := responseDuration(request.Params, "duration", tokenLifetime())
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.RLock()
+	tokens := s.tokens
+	s.mu.RUnlock()
+	if err := tokens.RevokeUserTokens(request.Event.UserID, ttl); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("已撤销用户 %d 的所有token", request.Event.UserID), nil
+}
+
+// requireMFA 标记用户需要重新进行MFA验证
+func (s *SecurityResponseService) requireMFA(ctx context.Context, request ResponseActionRequest) (string, error) {
+	if request.Event.UserID == 0 {
+		return "", fmt.Errorf("事件缺少用户ID")
+	}
+	ttl, err := responseDuration(request.Params, "duration", tokenLifetime())
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.RLock()
+	tokens := s.tokens
+	s.mu.RUnlock()
+	if err := tokens.RequireMFAReverification(request.Event.UserID, ttl); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("已要求用户 %d 重新进行MFA验证", request.Event.UserID), nil
+}
+
+// disableAPIKey 禁用API密钥：指定 key_id 时禁用该密钥，否则禁用用户所有启用中的密钥
+func (s *SecurityResponseService) disableAPIKey(ctx context.Context, request ResponseActionRequest) (string, error) {
+	if request.Event.UserID == 0 {
+		return "", fmt.Errorf("事件缺少用户ID")
+	}
+
+	query := s.db.WithContext(ctx).Model(&Models.ApiKey{}).Where("user_id = ? AND status = ?", request.Event.UserID, 1)
+	if keyID := request.Params["key_id"]; keyID != "" {
+		id, err := strconv.ParseUint(keyID, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("无效的 key_id 参数: %s", keyID)
+		}
+		query = query.Where("id = ?", id)
+	}
+
+	result := query.Update("status", 0)
+	if result.Error != nil {
+		return "", fmt.Errorf("禁用API密钥失败: %v", result.Error)
+	}
+	return fmt.Sprintf("已禁用用户 %d 的 %d 个API密钥", request.Event.UserID, result.RowsAffected), nil
+}
+
+// notify 发送通知：channel 参数指定通道名称，为空时发送到所有启用的通道
+func (s *SecurityResponseService) notify(ctx context.Context, request ResponseActionRequest) (string, error) {
+	s.mu.RLock()
+	channels := append([]NotificationChannel(nil), s.channels...)
+	s.mu.RUnlock()
+
+	severity := request.Params["severity"]
+	if severity == "" {
+		severity = "warning"
+		if request.Event.RiskScore >= 0.9 {
+			severity = "critical"
+		}
+	}
+	alert := MonitoringAlert{
+		ID:        fmt.Sprintf("security_response_%s_%d_%d", request.Playbook, request.Event.ID, time.Now().UnixNano()),
+		Type:      "security_response",
+		Severity:  severity,
+		Title:     fmt.Sprintf("安全事件自动响应: %s", request.Event.EventType),
+		Message:   fmt.Sprintf("剧本 %s 被事件 %s 触发（风险评分 %.2f）", request.Playbook, request.Event.EventType, request.Event.RiskScore),
+		Source:    "security",
+		Timestamp: time.Now(),
+		Metadata: map[string]interface{}{
+			"playbook":   request.Playbook,
+			"event_id":   request.Event.ID,
+			"event_type": request.Event.EventType,
+			"user_id":    request.Event.UserID,
+			"ip_address": request.Event.IPAddress,
+			"risk_score": request.Event.RiskScore,
+		},
+		RequestID:     request.Event.RequestID,
+		CorrelationID: request.Event.CorrelationID,
+	}
+
+	target := request.Params["channel"]
+	var sent []string
+	var errs []string
+	for _, channel := range channels {
+		if !channel.IsEnabled() || (target != "" && channel.GetName() != target) {
+			continue
+		}
+		if err := channel.SendAlert(alert); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", channel.GetName(), err))
+			continue
+		}
+		sent = append(sent, channel.GetName())
+	}
+
+	sort.Strings(sent)
+	if len(errs) > 0 {
+		return strings.Join(sent, ","), fmt.Errorf("发送通知失败: %s", strings.Join(errs, "; "))
+	}
+	if len(sent) == 0 {
+		return "", fmt.Errorf("没有可用的通知通道")
+	}
+	return fmt.Sprintf("已通知: %s", strings.Join(sent, ",")), nil
+}
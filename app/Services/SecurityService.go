@@ -2,12 +2,17 @@ package Services
 
 import (
 	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Database"
 	"cloud-platform-api/app/Models"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
-	"net/http"
+	"log"
+	"os"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
@@ -18,13 +23,20 @@ import (
 )
 
 // SecurityService 安全防护服务
+// 数据库查询使用调用方传入的上下文，请求超时或取消后查询随之取消；威胁情报更新、模型训练等后台任务使用服务自身的上下文，Close 时取消
 type SecurityService struct {
 	db              *gorm.DB
+	uow             *Database.UnitOfWork
 	config          *Config.SecurityConfig
 	commonPasswords map[string]bool
 	phishingURLs    map[string]bool
 	threatIPs       map[string]bool
 	threatDetection *ThreatDetectionService
+	threatExporter  *ThreatIntelExporter
+	anomalyModel    AnomalyModel
+	behaviorProfile *BehaviorProfileService
+	responseService *SecurityResponseService
+	lockoutService  *AccountLockoutService
 	mu              sync.RWMutex
 	ctx             context.Context
 	cancel          context.CancelFunc
@@ -42,6 +54,7 @@ func NewSecurityService(db *gorm.DB, config *Config.SecurityConfig) *SecuritySer
 
 	service := &SecurityService{
 		db:              db,
+		uow:             Database.NewUnitOfWork(db),
 		config:          config,
 		commonPasswords: make(map[string]bool),
 		phishingURLs:    make(map[string]bool),
@@ -52,6 +65,10 @@ func NewSecurityService(db *gorm.DB, config *Config.SecurityConfig) *SecuritySer
 
 	// 初始化威胁检测服务
 	service.threatDetection = NewThreatDetectionService(db, config)
+	service.threatExporter = NewThreatIntelExporter(db)
+	service.behaviorProfile = NewBehaviorProfileService(db, &config.AnomalyDetection)
+	service.responseService = NewSecurityResponseService(db, config)
+	service.lockoutService = NewAccountLockoutService(db, &config.AccountUnlock, nil)
 
 	// 初始化服务
 	service.initialize()
@@ -75,6 +92,18 @@ func (s *SecurityService) initialize() {
 	// 加载威胁情报
 	s.loadThreatIntelligence()
 
+	// 加载自动响应剧本
+	if s.responseService.Enabled() && s.config.Response.PlaybooksFile != "" {
+		if err := s.responseService.LoadPlaybooks(s.config.Response.PlaybooksFile); err != nil {
+			log.Printf("加载自动响应剧本失败: %v", err)
+		}
+	}
+
+	// 加载异常检测模型
+	if s.config.AnomalyDetection.MachineLearningEnabled {
+		s.initAnomalyModel()
+	}
+
 	// 启动定期更新任务
 	go s.startPeriodicUpdates()
 }
@@ -164,7 +193,7 @@ func (s *SecurityService) loadThreatIntelligence() {
 
 	// 从数据库加载威胁情报
 	var threats []Models.ThreatIntelligence
-	s.db.Where("active = ?", true).Find(&threats)
+	s.db.WithContext(s.ctx).Where("active = ?", true).Find(&threats)
 
 	s.mu.Lock()
 	for _, threat := range threats {
@@ -203,7 +232,8 @@ func (s *SecurityService) updateThreatIntelligence() {
 
 // fetchThreatIntelligence 获取威胁情报
 func (s *SecurityService) fetchThreatIntelligence(url string) {
-	resp, err := http.Get(url)
+	dependency := "threat_intel:" + outboundHost(url)
+	resp, err := threatIntelClient(dependency).Get(s.ctx, dependency, url, nil)
 	if err != nil {
 		return
 	}
@@ -391,13 +421,13 @@ func (s *SecurityService) calculatePasswordStrength(password string) float64 {
 }
 
 // CheckPasswordHistory 检查密码历史
-func (s *SecurityService) CheckPasswordHistory(userID uint, passwordHash string) bool {
+func (s *SecurityService) CheckPasswordHistory(ctx context.Context, userID uint, passwordHash string) bool {
 	if s.config.BaseSecurity.PasswordHistoryCount <= 0 {
 		return true
 	}
 
 	var count int64
-	s.db.Model(&Models.PasswordHistory{}).
+	s.db.WithContext(ctx).Model(&Models.PasswordHistory{}).
 		Where("user_id = ? AND password_hash = ?", userID, passwordHash).
 		Count(&count)
 
@@ -405,65 +435,67 @@ func (s *SecurityService) CheckPasswordHistory(userID uint, passwordHash string)
 }
 
 // RecordPasswordChange 记录密码更改
-func (s *SecurityService) RecordPasswordChange(userID, changedBy uint, passwordHash, reason, ipAddress, userAgent string) error {
-	history := Models.PasswordHistory{
-		UserID:       userID,
-		PasswordHash: passwordHash,
-		ChangedAt:    time.Now(),
-		ChangedBy:    changedBy,
-		Reason:       reason,
-		IPAddress:    ipAddress,
-		UserAgent:    userAgent,
-	}
-
-	// 保存密码历史
-	if err := s.db.Create(&history).Error; err != nil {
-		return err
-	}
-
-	// 清理旧的密码历史
-	if s.config.BaseSecurity.PasswordHistoryCount > 0 {
-		var histories []Models.PasswordHistory
-		s.db.Where("user_id = ?", userID).
-			Order("changed_at DESC").
-			Limit(s.config.BaseSecurity.PasswordHistoryCount + 1).
-			Find(&histories)
+// 保存密码历史和清理旧的密码历史在同一事务中，清理失败时不保存
+func (s *SecurityService) RecordPasswordChange(ctx context.Context, userID, changedBy uint, passwordHash, reason, ipAddress, userAgent string) error {
+	changedAt := time.Now()
+	return s.uow.WithTransaction(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		history := Models.PasswordHistory{
+			UserID:       userID,
+			PasswordHash: passwordHash,
+			ChangedAt:    changedAt,
+			ChangedBy:    changedBy,
+			Reason:       reason,
+			IPAddress:    ipAddress,
+			UserAgent:    userAgent,
+		}
 
-		if len(histories) > s.config.BaseSecurity.PasswordHistoryCount {
-			oldHistories := histories[s.config.BaseSecurity.PasswordHistoryCount:]
-			for _, old := range oldHistories {
-				s.db.Delete(&old)
-			}
+		// 保存密码历史
+		if err := tx.Create(&history).Error; err != nil {
+			return err
 		}
-	}
 
-	return nil
+		// 清理旧的密码历史
+		keep := s.config.BaseSecurity.PasswordHistoryCount
+		if keep <= 0 {
+			return nil
+		}
+		var ids []uint
+		err := tx.Model(&Models.PasswordHistory{}).Where("user_id = ?", userID).
+			Order("changed_at DESC, id DESC").
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) <= keep {
+			return err
+		}
+		return tx.Delete(&Models.PasswordHistory{}, ids[keep:]).Error
+	})
 }
 
 // CheckLoginAttempts 检查登录尝试
-func (s *SecurityService) CheckLoginAttempts(username, ipAddress string) (bool, string) {
+func (s *SecurityService) CheckLoginAttempts(ctx context.Context, username, ipAddress string) (bool, string) {
 	// 检查账户锁定
 	var lockout Models.AccountLockout
-	if err := s.db.Where("username = ? AND active = ? AND expiry_time > ?", username, true, time.Now()).First(&lockout).Error; err == nil {
+	if err := s.db.WithContext(ctx).Where("username = ? AND active = ? AND expiry_time > ?", username, true, time.Now()).First(&lockout).Error; err == nil {
 		return false, "账户已被锁定"
 	}
 
 	// 检查IP锁定
-	if err := s.db.Where("ip_address = ? AND active = ? AND expiry_time > ?", ipAddress, true, time.Now()).First(&lockout).Error; err == nil {
+	if err := s.db.WithContext(ctx).Where("ip_address = ? AND active = ? AND expiry_time > ?", ipAddress, true, time.Now()).First(&lockout).Error; err == nil {
 		return false, "IP地址已被锁定"
 	}
 
 	// 检查登录尝试次数
 	var attemptCount int64
-	s.db.Model(&Models.LoginAttempt{}).
+	s.db.WithContext(ctx).Model(&Models.LoginAttempt{}).
 		Where("username = ? AND ip_address = ? AND success = ? AND attempt_time > ?",
 			username, ipAddress, false, time.Now().Add(-s.config.BaseSecurity.LoginLockoutDuration)).
 		Count(&attemptCount)
 
 	if int(attemptCount) >= s.config.BaseSecurity.MaxLoginAttempts {
-		// 创建锁定记录
+		// 创建锁定记录，用户不存在时用户ID为0
+		var userID uint
+		s.db.WithContext(ctx).Model(&Models.User{}).Select("id").Where("username = ?", username).Limit(1).Scan(&userID)
 		lockout = Models.AccountLockout{
-			UserID:       0, // 未知用户ID
+			UserID:       userID,
 			Username:     username,
 			IPAddress:    ipAddress,
 			LockoutType:  "login_attempts",
@@ -473,7 +505,14 @@ func (s *SecurityService) CheckLoginAttempts(username, ipAddress string) (bool,
 			AttemptCount: int(attemptCount),
 			Active:       true,
 		}
-		s.db.Create(&lockout)
+		if err := s.db.WithContext(ctx).Create(&lockout).Error; err == nil {
+			// 异步发送自助解锁邮件，避免阻塞登录请求
+			go func(lockout Models.AccountLockout) {
+				if err := s.lockoutService.NotifyLockout(&lockout); err != nil {
+					log.Printf("发送账户解锁邮件失败: %v", err)
+				}
+			}(lockout)
+		}
 
 		return false, "登录尝试次数过多，账户已被锁定"
 	}
@@ -482,7 +521,7 @@ func (s *SecurityService) CheckLoginAttempts(username, ipAddress string) (bool,
 }
 
 // RecordLoginAttempt 记录登录尝试
-func (s *SecurityService) RecordLoginAttempt(username, ipAddress, userAgent, failureReason string, success bool, location, deviceInfo string) error {
+func (s *SecurityService) RecordLoginAttempt(ctx context.Context, username, ipAddress, userAgent, failureReason string, success bool, location, deviceInfo string) error {
 	attempt := Models.LoginAttempt{
 		Username:      username,
 		IPAddress:     ipAddress,
@@ -492,15 +531,23 @@ func (s *SecurityService) RecordLoginAttempt(username, ipAddress, userAgent, fai
 		AttemptTime:   time.Now(),
 		Location:      location,
 		DeviceInfo:    deviceInfo,
-		RiskScore:     s.calculateLoginRiskScore(username, ipAddress, userAgent),
+		RiskScore:     s.calculateLoginRiskScore(ctx, username, ipAddress, userAgent),
 		Blocked:       false,
 	}
 
-	return s.db.Create(&attempt).Error
+	if err := s.db.WithContext(ctx).Create(&attempt).Error; err != nil {
+		return err
+	}
+
+	// 成功登录计入用户行为画像
+	if success && s.config.AnomalyDetection.Enabled && s.config.AnomalyDetection.BehavioralAnalysis {
+		s.recordLoginBehavior(ctx, username, ipAddress, userAgent, location, deviceInfo)
+	}
+	return nil
 }
 
 // calculateLoginRiskScore 计算登录风险评分
-func (s *SecurityService) calculateLoginRiskScore(username, ipAddress, userAgent string) float64 {
+func (s *SecurityService) calculateLoginRiskScore(ctx context.Context, username, ipAddress, userAgent string) float64 {
 	score := 0.0
 
 	// 检查威胁IP
@@ -523,7 +570,7 @@ func (s *SecurityService) calculateLoginRiskScore(username, ipAddress, userAgent
 
 	// 检查登录模式
 	var recentAttempts int64
-	s.db.Model(&Models.LoginAttempt{}).
+	s.db.WithContext(ctx).Model(&Models.LoginAttempt{}).
 		Where("ip_address = ? AND attempt_time > ?", ipAddress, time.Now().Add(-time.Hour)).
 		Count(&recentAttempts)
 
@@ -538,9 +585,9 @@ func (s *SecurityService) calculateLoginRiskScore(username, ipAddress, userAgent
 }
 
 // CheckAccessControl 检查访问控制
-func (s *SecurityService) CheckAccessControl(userID uint, resource, action string) (bool, string) {
+func (s *SecurityService) CheckAccessControl(ctx context.Context, userID uint, resource, action string) (bool, string) {
 	var controls []Models.AccessControl
-	s.db.Where("user_id = ? AND resource = ? AND action = ? AND active = ?",
+	s.db.WithContext(ctx).Where("user_id = ? AND resource = ? AND action = ? AND active = ?",
 		userID, resource, action, true).
 		Order("priority DESC").
 		Find(&controls)
@@ -581,6 +628,116 @@ func (s *SecurityService) CheckAccessControl(userID uint, resource, action strin
 	return control.Permission == "allow", control.Permission
 }
 
+// ErrInvalidAccessControl 访问控制条目无效
+var ErrInvalidAccessControl = errors.New("访问控制条目无效")
+
+// AccessControlInput 访问控制条目的可修改字段，更新时为 nil 的字段保持不变
+type AccessControlInput struct {
+	UserID              *uint   `json:"user_id"`
+	Resource            *string `json:"resource"`
+	Action              *string `json:"action"`
+	Permission          *string `json:"permission"` // allow 或 deny
+	Condition           *string `json:"condition"`
+	TimeRestriction     *string `json:"time_restriction"`
+	LocationRestriction *string `json:"location_restriction"`
+	DeviceRestriction   *string `json:"device_restriction"`
+	Priority            *int    `json:"priority"`
+	Active              *bool   `json:"active"`
+	Version             uint    `json:"version"` // 更新时期望的版本，可用 If-Match 请求头代替
+}
+
+// apply 把传入的字段写入条目并检查必填字段
+func (input AccessControlInput) apply(control *Models.AccessControl) error {
+	set := func(dst *string, value *string) {
+		if value != nil {
+			*dst = strings.TrimSpace(*value)
+		}
+	}
+	if input.UserID != nil {
+		control.UserID = *input.UserID
+	}
+	set(&control.Resource, input.Resource)
+	set(&control.Action, input.Action)
+	set(&control.Permission, input.Permission)
+	set(&control.Condition, input.Condition)
+	set(&control.TimeRestriction, input.TimeRestriction)
+	set(&control.LocationRestriction, input.LocationRestriction)
+	set(&control.DeviceRestriction, input.DeviceRestriction)
+	if input.Priority != nil {
+		control.Priority = *input.Priority
+	}
+	if input.Active != nil {
+		control.Active = *input.Active
+	}
+
+	switch {
+	case control.UserID == 0:
+		return fmt.Errorf("%w：用户ID不能为空", ErrInvalidAccessControl)
+	case control.Resource == "" || control.Action == "":
+		return fmt.Errorf("%w：资源和操作不能为空", ErrInvalidAccessControl)
+	case control.Permission != "allow" && control.Permission != "deny":
+		return fmt.Errorf("%w：权限只能是 allow 或 deny", ErrInvalidAccessControl)
+	}
+	return nil
+}
+
+// ListAccessControls 按优先级列出访问控制条目，userID 为 0 时返回全部用户的条目
+func (s *SecurityService) ListAccessControls(ctx context.Context, userID uint) ([]Models.AccessControl, error) {
+	query := s.db.WithContext(ctx).Order("priority DESC, id")
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	var controls []Models.AccessControl
+	err := query.Find(&controls).Error
+	return controls, err
+}
+
+// GetAccessControl 获取访问控制条目，不存在时返回 gorm.ErrRecordNotFound
+func (s *SecurityService) GetAccessControl(ctx context.Context, id uint) (*Models.AccessControl, error) {
+	var control Models.AccessControl
+	if err := s.db.WithContext(ctx).First(&control, id).Error; err != nil {
+		return nil, err
+	}
+	return &control, nil
+}
+
+// CreateAccessControl 创建访问控制条目，未指定时默认启用
+func (s *SecurityService) CreateAccessControl(ctx context.Context, input AccessControlInput) (*Models.AccessControl, error) {
+	control := &Models.AccessControl{Active: true, Version: 1}
+	if err := input.apply(control); err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Create(control).Error; err != nil {
+		return nil, err
+	}
+	// active 的数据库默认值为 true，创建时的 false 会被替换为默认值，需要单独更新
+	if input.Active != nil && !*input.Active {
+		if err := s.db.WithContext(ctx).Model(control).Update("active", false).Error; err != nil {
+			return nil, err
+		}
+	}
+	return control, nil
+}
+
+// UpdateAccessControl 使用乐观锁更新访问控制条目
+// 功能说明：
+// 1. 只修改 input 中传入的字段
+// 2. expected 为期望的版本，为 0 时按读取时的版本检查
+// 3. 版本不一致时返回 *Models.VersionConflictError，条目不存在时返回 gorm.ErrRecordNotFound
+func (s *SecurityService) UpdateAccessControl(ctx context.Context, id uint, input AccessControlInput, expected uint) (*Models.AccessControl, error) {
+	control, err := s.GetAccessControl(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := input.apply(control); err != nil {
+		return nil, err
+	}
+	if err := Models.SaveWithVersion(s.db.WithContext(ctx), control, expected); err != nil {
+		return nil, err
+	}
+	return control, nil
+}
+
 // checkTimeRestriction 检查时间限制
 func (s *SecurityService) checkTimeRestriction(restriction string) bool {
 	// 示例：检查工作时间限制
@@ -597,41 +754,226 @@ func (s *SecurityService) checkTimeRestriction(restriction string) bool {
 }
 
 // DetectAnomaly 异常检测
-func (s *SecurityService) DetectAnomaly(userID uint, eventType, resource, action, ipAddress, userAgent string) (bool, float64) {
+func (s *SecurityService) DetectAnomaly(ctx context.Context, userID uint, eventType, resource, action, ipAddress, userAgent string) (bool, float64) {
 	if !s.config.AnomalyDetection.Enabled {
 		return false, 0
 	}
 
 	score := 0.0
+	observation := AnomalyObservation{
+		UserID:    userID,
+		EventType: eventType,
+		Resource:  resource,
+		Action:    action,
+		IPAddress: ipAddress,
+		Time:      time.Now(),
+	}
 
 	// 行为分析
+	var deviations []BehaviorDeviation
 	if s.config.AnomalyDetection.BehavioralAnalysis {
-		behaviorScore := s.analyzeBehavior(userID, eventType, resource, action)
+		behaviorScore := s.analyzeBehavior(ctx, userID, eventType, resource, action)
 		score += behaviorScore
+
+		// 与用户行为画像比较
+		if userID != 0 {
+			profileDeviations, deviationScore, err := s.behaviorProfile.Evaluate(ctx, observation)
+			if err != nil {
+				log.Printf("更新用户行为画像失败: %v", err)
+			}
+			deviations = profileDeviations
+			score += deviationScore
+		}
 	}
 
 	// 模式识别
 	if s.config.AnomalyDetection.PatternRecognition {
-		patternScore := s.analyzePattern(userID, eventType, resource, action, ipAddress)
+		patternScore := s.analyzePattern(ctx, userID, eventType, resource, action, ipAddress)
 		score += patternScore
 	}
 
+	// 模型评分：学习期内只学习，之后按用户行为基线给出0-1的分数
+	if model := s.GetAnomalyModel(); model != nil && userID != 0 {
+		model.Observe(observation)
+		if modelScore, ready := model.Score(observation); ready {
+			score += modelScore
+		}
+	}
+
 	// 检查是否超过阈值
 	isAnomaly := score > s.config.AnomalyDetection.AnomalyScoreThreshold
 
-	// 记录安全事件
-	s.RecordSecurityEvent(userID, eventType, "medium", ipAddress, userAgent, resource, action, "", score, score, false, false, "", "")
+	// 记录安全事件，偏离基线的说明写入详情
+	s.RecordSecurityEvent(ctx, userID, eventType, "medium", ipAddress, userAgent, resource, action, behaviorDeviationDetails(deviations), score, score, false, false, "", "")
 
 	return isAnomaly, score
 }
 
+// initAnomalyModel 创建异常检测模型，优先加载已保存的状态，否则用学习周期内的安全事件训练
+func (s *SecurityService) initAnomalyModel() {
+	model, err := NewAnomalyModel(&s.config.AnomalyDetection)
+	if err != nil {
+		log.Printf("创建异常检测模型失败: %v", err)
+		return
+	}
+
+	if err := loadAnomalyModel(model, s.config.AnomalyDetection.MLModelPath); err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("加载异常检测模型失败，重新训练: %v", err)
+		}
+		if err := s.trainAnomalyModel(model); err != nil {
+			log.Printf("训练异常检测模型失败: %v", err)
+		}
+	}
+	s.SetAnomalyModel(model)
+
+	if interval := s.config.AnomalyDetection.AnalysisInterval; interval > 0 {
+		go s.persistAnomalyModel(interval)
+	}
+}
+
+// trainAnomalyModel 用学习周期内的安全事件训练模型
+func (s *SecurityService) trainAnomalyModel(model AnomalyModel) error {
+	since := time.Now().Add(-s.config.AnomalyDetection.LearningPeriod)
+	var events []Models.SecurityEvent
+	return s.db.WithContext(s.ctx).Where("user_id IS NOT NULL AND user_id <> 0 AND created_at > ?", since).
+		Order("id ASC").
+		FindInBatches(&events, 1000, func(tx *gorm.DB, batch int) error {
+			for _, event := range events {
+				model.Observe(AnomalyObservation{
+					UserID:    *event.UserID,
+					EventType: event.EventType,
+					Resource:  event.Resource,
+					Action:    event.Action,
+					IPAddress: event.IPAddress,
+					Time:      event.CreatedAt,
+				})
+			}
+			return nil
+		}).Error
+}
+
+// persistAnomalyModel 按分析间隔保存模型状态
+func (s *SecurityService) persistAnomalyModel(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.SaveAnomalyModel(); err != nil {
+				log.Printf("保存异常检测模型失败: %v", err)
+			}
+		}
+	}
+}
+
+// loadAnomalyModel 从文件加载模型状态
+func loadAnomalyModel(model AnomalyModel, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return model.Load(file)
+}
+
+// GetAnomalyModel 获取异常检测模型，未启用机器学习时返回 nil
+func (s *SecurityService) GetAnomalyModel() AnomalyModel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.anomalyModel
+}
+
+// SetAnomalyModel 设置异常检测模型，用于替换为自定义实现
+func (s *SecurityService) SetAnomalyModel(model AnomalyModel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.anomalyModel = model
+}
+
+// SaveAnomalyModel 将模型状态保存到 MLModelPath，先写临时文件再替换，避免写入中断损坏已有状态
+func (s *SecurityService) SaveAnomalyModel() error {
+	model := s.GetAnomalyModel()
+	path := s.config.AnomalyDetection.MLModelPath
+	if model == nil || path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := model.Save(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// GetUserBehaviorProfile 获取用户行为画像
+func (s *SecurityService) GetUserBehaviorProfile(ctx context.Context, userID uint) (*Models.UserBehaviorProfile, error) {
+	return s.behaviorProfile.GetProfile(ctx, userID)
+}
+
+// GetBehaviorProfileService 获取用户行为画像服务
+func (s *SecurityService) GetBehaviorProfileService() *BehaviorProfileService {
+	return s.behaviorProfile
+}
+
+// recordLoginBehavior 将成功登录计入用户画像，偏离基线时记录异常登录事件
+func (s *SecurityService) recordLoginBehavior(ctx context.Context, username, ipAddress, userAgent, location, deviceInfo string) {
+	var user Models.User
+	if err := s.db.WithContext(ctx).Select("id").Where("username = ?", username).First(&user).Error; err != nil {
+		return
+	}
+
+	deviations, score, err := s.behaviorProfile.Evaluate(ctx, AnomalyObservation{
+		UserID:    user.ID,
+		EventType: BehaviorEventLogin,
+		Resource:  "login",
+		IPAddress: ipAddress,
+		Time:      time.Now(),
+	})
+	if err != nil {
+		log.Printf("更新用户行为画像失败: %v", err)
+	}
+	if len(deviations) == 0 {
+		return
+	}
+
+	s.RecordSecurityEvent(ctx, user.ID, "unusual_login", "medium", ipAddress, userAgent, "login", "login", behaviorDeviationDetails(deviations), score, score, false, false, location, deviceInfo)
+}
+
+// behaviorDeviationDetails 将偏离说明序列化为安全事件详情
+func behaviorDeviationDetails(deviations []BehaviorDeviation) string {
+	if len(deviations) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(map[string]interface{}{"deviations": deviations})
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
 // analyzeBehavior 行为分析
-func (s *SecurityService) analyzeBehavior(userID uint, eventType, resource, action string) float64 {
+func (s *SecurityService) analyzeBehavior(ctx context.Context, userID uint, eventType, resource, action string) float64 {
 	score := 0.0
 
 	// 检查用户历史行为
 	var eventCount int64
-	s.db.Model(&Models.SecurityEvent{}).
+	s.db.WithContext(ctx).Model(&Models.SecurityEvent{}).
 		Where("user_id = ? AND event_type = ? AND created_at > ?",
 			userID, eventType, time.Now().Add(-24*time.Hour)).
 		Count(&eventCount)
@@ -643,7 +985,7 @@ func (s *SecurityService) analyzeBehavior(userID uint, eventType, resource, acti
 
 	// 检查资源访问模式
 	var resourceCount int64
-	s.db.Model(&Models.SecurityEvent{}).
+	s.db.WithContext(ctx).Model(&Models.SecurityEvent{}).
 		Where("user_id = ? AND resource = ? AND created_at > ?",
 			userID, resource, time.Now().Add(-time.Hour)).
 		Count(&resourceCount)
@@ -656,12 +998,12 @@ func (s *SecurityService) analyzeBehavior(userID uint, eventType, resource, acti
 }
 
 // analyzePattern 模式识别
-func (s *SecurityService) analyzePattern(userID uint, eventType, resource, action, ipAddress string) float64 {
+func (s *SecurityService) analyzePattern(ctx context.Context, userID uint, eventType, resource, action, ipAddress string) float64 {
 	score := 0.0
 
 	// 检查IP地址变化
 	var recentIPs []string
-	s.db.Model(&Models.SecurityEvent{}).
+	s.db.WithContext(ctx).Model(&Models.SecurityEvent{}).
 		Where("user_id = ? AND created_at > ?", userID, time.Now().Add(-time.Hour)).
 		Pluck("ip_address", &recentIPs)
 
@@ -676,7 +1018,7 @@ func (s *SecurityService) analyzePattern(userID uint, eventType, resource, actio
 
 	// 检查时间模式
 	var recentEvents []Models.SecurityEvent
-	s.db.Where("user_id = ? AND created_at > ?", userID, time.Now().Add(-time.Hour)).
+	s.db.WithContext(ctx).Where("user_id = ? AND created_at > ?", userID, time.Now().Add(-time.Hour)).
 		Find(&recentEvents)
 
 	if len(recentEvents) > 0 {
@@ -693,7 +1035,7 @@ func (s *SecurityService) analyzePattern(userID uint, eventType, resource, actio
 }
 
 // RecordSecurityEvent 记录安全事件
-func (s *SecurityService) RecordSecurityEvent(userID uint, eventType, eventLevel, ipAddress, userAgent, resource, action, details string, riskScore, anomalyScore float64, blocked, alerted bool, location, deviceInfo string) error {
+func (s *SecurityService) RecordSecurityEvent(ctx context.Context, userID uint, eventType, eventLevel, ipAddress, userAgent, resource, action, details string, riskScore, anomalyScore float64, blocked, alerted bool, location, deviceInfo string) error {
 	event := Models.SecurityEvent{
 		EventType:    eventType,
 		EventLevel:   eventLevel,
@@ -711,7 +1053,124 @@ func (s *SecurityService) RecordSecurityEvent(userID uint, eventType, eventLevel
 		DeviceInfo:   deviceInfo,
 	}
 
-	return s.db.Create(&event).Error
+	// 同一事务中写入高级别安全事件，供Webhook订阅等外部集成使用
+	err := s.uow.WithTransaction(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		if err := tx.Create(&event).Error; err != nil {
+			return err
+		}
+		return publishSecurityEvent(tx, &event)
+	})
+	if err != nil {
+		return err
+	}
+
+	// 高级别或已告警的事件通知管理员
+	if notifications := DefaultNotificationService(); notifications != nil {
+		go notifications.NotifySecurityAlert(&event)
+	}
+
+	// 按剧本自动响应
+	if s.responseService.Enabled() {
+		if _, err := s.responseService.HandleEvent(s.ctx, ResponseEvent{
+			ID:            event.ID,
+			EventType:     eventType,
+			UserID:        userID,
+			IPAddress:     ipAddress,
+			RiskScore:     riskScore,
+			Details:       details,
+			RequestID:     event.RequestID,
+			CorrelationID: event.CorrelationID,
+		}); err != nil {
+			log.Printf("安全事件自动响应失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// publishSecurityEvent 发布 high 和 critical 级别的安全事件，其他级别忽略
+func publishSecurityEvent(tx *gorm.DB, event *Models.SecurityEvent) error {
+	if event.EventLevel != "high" && event.EventLevel != "critical" {
+		return nil
+	}
+	payload := map[string]interface{}{
+		"security_event_id": event.ID,
+		"event_type":        event.EventType,
+		"level":             event.EventLevel,
+		"ip_address":        event.IPAddress,
+		"resource":          event.Resource,
+		"action":            event.Action,
+		"details":           event.Details,
+		"risk_score":        event.RiskScore,
+		"blocked":           event.Blocked,
+	}
+	if event.UserID != nil {
+		payload["user_id"] = *event.UserID
+	}
+	return PublishDomainEvent(tx, EventSecurityEventHigh, "security_event", fmt.Sprint(event.ID), payload)
+}
+
+// IP封禁状态缓存参数
+const (
+	ipBlockCacheTTL        = 5 * time.Second // 封禁状态缓存时间，其他实例的封禁和解锁最多延迟这么久生效
+	ipBlockCacheMaxEntries = 10000           // 缓存的IP数超过上限时清空
+)
+
+// ipBlockCacheEntry IP封禁状态缓存条目
+type ipBlockCacheEntry struct {
+	blocked   bool
+	expiresAt time.Time
+}
+
+// ipBlockCache IP封禁状态缓存，进程内的安全服务实例共享，本实例封禁或解锁IP时清空
+var ipBlockCache = struct {
+	mu      sync.Mutex
+	entries map[string]ipBlockCacheEntry
+}{entries: make(map[string]ipBlockCacheEntry)}
+
+// InvalidateIPBlockCache 清空IP封禁状态缓存，写入或解除IP锁定后调用
+func InvalidateIPBlockCache() {
+	ipBlockCache.mu.Lock()
+	ipBlockCache.entries = make(map[string]ipBlockCacheEntry)
+	ipBlockCache.mu.Unlock()
+}
+
+// IsIPBlocked 检查IP是否被自动响应封禁
+// 查询结果缓存 ipBlockCacheTTL，避免每个请求都查询数据库；查询失败时不缓存并按未封禁处理
+func (s *SecurityService) IsIPBlocked(ctx context.Context, ipAddress string) bool {
+	now := time.Now()
+	ipBlockCache.mu.Lock()
+	entry, ok := ipBlockCache.entries[ipAddress]
+	ipBlockCache.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.blocked
+	}
+
+	var count int64
+	err := s.db.WithContext(ctx).Model(&Models.AccountLockout{}).
+		Where("ip_address = ? AND lockout_type = ? AND active = ? AND expiry_time > ?", ipAddress, ResponseLockoutType, true, now).
+		Count(&count).Error
+	if err != nil {
+		log.Printf("查询IP封禁状态失败: ip=%s, error=%v", ipAddress, err)
+		return false
+	}
+
+	ipBlockCache.mu.Lock()
+	if len(ipBlockCache.entries) >= ipBlockCacheMaxEntries {
+		ipBlockCache.entries = make(map[string]ipBlockCacheEntry)
+	}
+	ipBlockCache.entries[ipAddress] = ipBlockCacheEntry{blocked: count > 0, expiresAt: now.Add(ipBlockCacheTTL)}
+	ipBlockCache.mu.Unlock()
+	return count > 0
+}
+
+// GetResponseService 获取自动响应服务
+func (s *SecurityService) GetResponseService() *SecurityResponseService {
+	return s.responseService
+}
+
+// GetAccountLockoutService 获取账户锁定管理服务
+func (s *SecurityService) GetAccountLockoutService() *AccountLockoutService {
+	return s.lockoutService
 }
 
 // CheckThreatProtection 威胁防护检查
@@ -777,7 +1236,7 @@ func (s *SecurityService) isMalwareFile(fileHash string) bool {
 }
 
 // GenerateSecurityReport 生成安全报告
-func (s *SecurityService) GenerateSecurityReport(reportType, period string, startDate, endDate time.Time, generatedBy uint) (*Models.SecurityReport, error) {
+func (s *SecurityService) GenerateSecurityReport(ctx context.Context, reportType, period string, startDate, endDate time.Time, generatedBy uint) (*Models.SecurityReport, error) {
 	report := &Models.SecurityReport{
 		ReportType:  reportType,
 		Title:       fmt.Sprintf("%s安全报告 - %s", reportType, period),
@@ -791,7 +1250,7 @@ func (s *SecurityService) GenerateSecurityReport(reportType, period string, star
 	}
 
 	// 生成报告内容
-	content, err := s.generateReportContent(reportType, startDate, endDate)
+	content, err := s.generateReportContent(ctx, reportType, startDate, endDate)
 	if err != nil {
 		return nil, err
 	}
@@ -799,20 +1258,26 @@ func (s *SecurityService) GenerateSecurityReport(reportType, period string, star
 	report.Content = content
 	report.Summary = s.generateReportSummary(content)
 
-	return report, s.db.Create(report).Error
+	return report, s.db.WithContext(ctx).Create(report).Error
 }
 
 // generateReportContent 生成报告内容
-func (s *SecurityService) generateReportContent(reportType string, startDate, endDate time.Time) (string, error) {
+func (s *SecurityService) generateReportContent(ctx context.Context, reportType string, startDate, endDate time.Time) (string, error) {
 	var content map[string]interface{}
 
 	switch reportType {
 	case "login_attempts":
-		content = s.generateLoginAttemptsReport(startDate, endDate)
+		content = s.generateLoginAttemptsReport(ctx, startDate, endDate)
 	case "security_events":
-		content = s.generateSecurityEventsReport(startDate, endDate)
+		content = s.generateSecurityEventsReport(ctx, startDate, endDate)
 	case "threat_intelligence":
-		content = s.generateThreatIntelligenceReport(startDate, endDate)
+		content = s.generateThreatIntelligenceReport(ctx, startDate, endDate)
+	case "account_lockouts":
+		stats, err := s.lockoutService.Statistics(ctx, startDate, endDate)
+		if err != nil {
+			return "", err
+		}
+		content = stats
 	default:
 		content = make(map[string]interface{})
 	}
@@ -826,86 +1291,92 @@ func (s *SecurityService) generateReportContent(reportType string, startDate, en
 }
 
 // generateLoginAttemptsReport 生成登录尝试报告
-func (s *SecurityService) generateLoginAttemptsReport(startDate, endDate time.Time) map[string]interface{} {
+func (s *SecurityService) generateLoginAttemptsReport(ctx context.Context, startDate, endDate time.Time) map[string]interface{} {
 	var totalAttempts, successfulAttempts, failedAttempts int64
 	var lockouts int64
 
-	s.db.Model(&Models.LoginAttempt{}).
+	s.db.WithContext(ctx).Model(&Models.LoginAttempt{}).
 		Where("attempt_time BETWEEN ? AND ?", startDate, endDate).
 		Count(&totalAttempts)
 
-	s.db.Model(&Models.LoginAttempt{}).
+	s.db.WithContext(ctx).Model(&Models.LoginAttempt{}).
 		Where("attempt_time BETWEEN ? AND ? AND success = ?", startDate, endDate, true).
 		Count(&successfulAttempts)
 
-	s.db.Model(&Models.LoginAttempt{}).
+	s.db.WithContext(ctx).Model(&Models.LoginAttempt{}).
 		Where("attempt_time BETWEEN ? AND ? AND success = ?", startDate, endDate, false).
 		Count(&failedAttempts)
 
-	s.db.Model(&Models.AccountLockout{}).
+	s.db.WithContext(ctx).Model(&Models.AccountLockout{}).
 		Where("lockout_time BETWEEN ? AND ?", startDate, endDate).
 		Count(&lockouts)
 
+	lockoutStats, err := s.lockoutService.Statistics(ctx, startDate, endDate)
+	if err != nil {
+		log.Printf("统计账户锁定失败: %v", err)
+	}
+
 	return map[string]interface{}{
 		"total_attempts":      totalAttempts,
 		"successful_attempts": successfulAttempts,
 		"failed_attempts":     failedAttempts,
 		"success_rate":        float64(successfulAttempts) / float64(totalAttempts) * 100,
 		"lockouts":            lockouts,
-		"top_failed_ips":      s.getTopFailedIPs(startDate, endDate),
-		"top_failed_users":    s.getTopFailedUsers(startDate, endDate),
+		"lockout_statistics":  lockoutStats,
+		"top_failed_ips":      s.getTopFailedIPs(ctx, startDate, endDate),
+		"top_failed_users":    s.getTopFailedUsers(ctx, startDate, endDate),
 	}
 }
 
 // generateSecurityEventsReport 生成安全事件报告
-func (s *SecurityService) generateSecurityEventsReport(startDate, endDate time.Time) map[string]interface{} {
+func (s *SecurityService) generateSecurityEventsReport(ctx context.Context, startDate, endDate time.Time) map[string]interface{} {
 	var totalEvents int64
 	var highRiskEvents int64
 
-	s.db.Model(&Models.SecurityEvent{}).
+	s.db.WithContext(ctx).Model(&Models.SecurityEvent{}).
 		Where("created_at BETWEEN ? AND ?", startDate, endDate).
 		Count(&totalEvents)
 
-	s.db.Model(&Models.SecurityEvent{}).
+	s.db.WithContext(ctx).Model(&Models.SecurityEvent{}).
 		Where("created_at BETWEEN ? AND ? AND risk_score > ?", startDate, endDate, 70).
 		Count(&highRiskEvents)
 
 	return map[string]interface{}{
 		"total_events":      totalEvents,
 		"high_risk_events":  highRiskEvents,
-		"risk_distribution": s.getRiskDistribution(startDate, endDate),
-		"event_types":       s.getEventTypeDistribution(startDate, endDate),
-		"top_sources":       s.getTopEventSources(startDate, endDate),
+		"risk_distribution": s.getRiskDistribution(ctx, startDate, endDate),
+		"event_types":       s.getEventTypeDistribution(ctx, startDate, endDate),
+		"top_sources":       s.getTopEventSources(ctx, startDate, endDate),
 	}
 }
 
 // generateThreatIntelligenceReport 生成威胁情报报告
-func (s *SecurityService) generateThreatIntelligenceReport(startDate, endDate time.Time) map[string]interface{} {
+func (s *SecurityService) generateThreatIntelligenceReport(ctx context.Context, startDate, endDate time.Time) map[string]interface{} {
 	var totalThreats int64
 	var activeThreats int64
 
-	s.db.Model(&Models.ThreatIntelligence{}).
+	s.db.WithContext(ctx).Model(&Models.ThreatIntelligence{}).
 		Where("created_at BETWEEN ? AND ?", startDate, endDate).
 		Count(&totalThreats)
 
-	s.db.Model(&Models.ThreatIntelligence{}).
+	s.db.WithContext(ctx).Model(&Models.ThreatIntelligence{}).
 		Where("active = ?", true).
 		Count(&activeThreats)
 
 	return map[string]interface{}{
 		"total_threats":         totalThreats,
 		"active_threats":        activeThreats,
-		"threat_types":          s.getThreatTypeDistribution(startDate, endDate),
-		"severity_distribution": s.getThreatSeverityDistribution(startDate, endDate),
-		"top_sources":           s.getTopThreatSources(startDate, endDate),
+		"threat_types":          s.getThreatTypeDistribution(ctx, startDate, endDate),
+		"severity_distribution": s.getThreatSeverityDistribution(ctx, startDate, endDate),
+		"top_sources":           s.getTopThreatSources(ctx, startDate, endDate),
 	}
 }
 
 // 辅助方法
-func (s *SecurityService) getTopFailedIPs(startDate, endDate time.Time) []map[string]interface{} {
+func (s *SecurityService) getTopFailedIPs(ctx context.Context, startDate, endDate time.Time) []map[string]interface{} {
 	var results []map[string]interface{}
 
-	rows, err := s.db.Model(&Models.LoginAttempt{}).
+	rows, err := s.db.WithContext(ctx).Model(&Models.LoginAttempt{}).
 		Select("ip_address, COUNT(*) as count").
 		Where("attempt_time BETWEEN ? AND ? AND success = ?", startDate, endDate, false).
 		Group("ip_address").
@@ -931,10 +1402,10 @@ func (s *SecurityService) getTopFailedIPs(startDate, endDate time.Time) []map[st
 	return results
 }
 
-func (s *SecurityService) getTopFailedUsers(startDate, endDate time.Time) []map[string]interface{} {
+func (s *SecurityService) getTopFailedUsers(ctx context.Context, startDate, endDate time.Time) []map[string]interface{} {
 	var results []map[string]interface{}
 
-	rows, err := s.db.Model(&Models.LoginAttempt{}).
+	rows, err := s.db.WithContext(ctx).Model(&Models.LoginAttempt{}).
 		Select("username, COUNT(*) as count").
 		Where("attempt_time BETWEEN ? AND ? AND success = ?", startDate, endDate, false).
 		Group("username").
@@ -960,13 +1431,13 @@ func (s *SecurityService) getTopFailedUsers(startDate, endDate time.Time) []map[
 	return results
 }
 
-func (s *SecurityService) getRiskDistribution(startDate, endDate time.Time) map[string]int {
+func (s *SecurityService) getRiskDistribution(ctx context.Context, startDate, endDate time.Time) map[string]int {
 	var results []struct {
 		RiskRange string
 		Count     int
 	}
 
-	s.db.Model(&Models.SecurityEvent{}).
+	s.db.WithContext(ctx).Model(&Models.SecurityEvent{}).
 		Select("CASE WHEN risk_score < 30 THEN 'low' WHEN risk_score < 70 THEN 'medium' ELSE 'high' END as risk_range, COUNT(*) as count").
 		Where("created_at BETWEEN ? AND ?", startDate, endDate).
 		Group("risk_range").
@@ -980,10 +1451,10 @@ func (s *SecurityService) getRiskDistribution(startDate, endDate time.Time) map[
 	return distribution
 }
 
-func (s *SecurityService) getEventTypeDistribution(startDate, endDate time.Time) []map[string]interface{} {
+func (s *SecurityService) getEventTypeDistribution(ctx context.Context, startDate, endDate time.Time) []map[string]interface{} {
 	var results []map[string]interface{}
 
-	rows, err := s.db.Model(&Models.SecurityEvent{}).
+	rows, err := s.db.WithContext(ctx).Model(&Models.SecurityEvent{}).
 		Select("event_type, COUNT(*) as count").
 		Where("created_at BETWEEN ? AND ?", startDate, endDate).
 		Group("event_type").
@@ -1008,10 +1479,10 @@ func (s *SecurityService) getEventTypeDistribution(startDate, endDate time.Time)
 	return results
 }
 
-func (s *SecurityService) getTopEventSources(startDate, endDate time.Time) []map[string]interface{} {
+func (s *SecurityService) getTopEventSources(ctx context.Context, startDate, endDate time.Time) []map[string]interface{} {
 	var results []map[string]interface{}
 
-	rows, err := s.db.Model(&Models.SecurityEvent{}).
+	rows, err := s.db.WithContext(ctx).Model(&Models.SecurityEvent{}).
 		Select("ip_address, COUNT(*) as count").
 		Where("created_at BETWEEN ? AND ?", startDate, endDate).
 		Group("ip_address").
@@ -1037,10 +1508,10 @@ func (s *SecurityService) getTopEventSources(startDate, endDate time.Time) []map
 	return results
 }
 
-func (s *SecurityService) getThreatTypeDistribution(startDate, endDate time.Time) []map[string]interface{} {
+func (s *SecurityService) getThreatTypeDistribution(ctx context.Context, startDate, endDate time.Time) []map[string]interface{} {
 	var results []map[string]interface{}
 
-	rows, err := s.db.Model(&Models.ThreatIntelligence{}).
+	rows, err := s.db.WithContext(ctx).Model(&Models.ThreatIntelligence{}).
 		Select("threat_type, COUNT(*) as count").
 		Where("created_at BETWEEN ? AND ?", startDate, endDate).
 		Group("threat_type").
@@ -1065,13 +1536,13 @@ func (s *SecurityService) getThreatTypeDistribution(startDate, endDate time.Time
 	return results
 }
 
-func (s *SecurityService) getThreatSeverityDistribution(startDate, endDate time.Time) map[string]int {
+func (s *SecurityService) getThreatSeverityDistribution(ctx context.Context, startDate, endDate time.Time) map[string]int {
 	var results []struct {
 		Severity string
 		Count    int
 	}
 
-	s.db.Model(&Models.ThreatIntelligence{}).
+	s.db.WithContext(ctx).Model(&Models.ThreatIntelligence{}).
 		Select("severity, COUNT(*) as count").
 		Where("created_at BETWEEN ? AND ?", startDate, endDate).
 		Group("severity").
@@ -1085,10 +1556,10 @@ func (s *SecurityService) getThreatSeverityDistribution(startDate, endDate time.
 	return distribution
 }
 
-func (s *SecurityService) getTopThreatSources(startDate, endDate time.Time) []map[string]interface{} {
+func (s *SecurityService) getTopThreatSources(ctx context.Context, startDate, endDate time.Time) []map[string]interface{} {
 	var results []map[string]interface{}
 
-	rows, err := s.db.Model(&Models.ThreatIntelligence{}).
+	rows, err := s.db.WithContext(ctx).Model(&Models.ThreatIntelligence{}).
 		Select("source, COUNT(*) as count").
 		Where("created_at BETWEEN ? AND ?", startDate, endDate).
 		Group("source").
@@ -1135,6 +1606,15 @@ func (s *SecurityService) generateReportSummary(content string) string {
 		summary += fmt.Sprintf("- 总威胁情报数: %.0f\n", totalThreats)
 	}
 
+	lockoutStats, ok := data["lockout_statistics"].(map[string]interface{})
+	if !ok {
+		lockoutStats = data
+	}
+	if totalLockouts, ok := lockoutStats["total_lockouts"].(float64); ok {
+		activeLockouts, _ := lockoutStats["active_lockouts"].(float64)
+		summary += fmt.Sprintf("- 账户锁定次数: %.0f（生效中 %.0f）\n", totalLockouts, activeLockouts)
+	}
+
 	return summary
 }
 
@@ -1159,8 +1639,23 @@ func (s *SecurityService) ExportThreatIntelligence(format string) ([]byte, error
 	return nil, fmt.Errorf("threat detection service not available")
 }
 
+// ExportThreatIndicators 按条件流式导出威胁情报指标
+func (s *SecurityService) ExportThreatIndicators(ctx context.Context, w io.Writer, format string, filter ThreatIntelExportFilter) (*ThreatIntelExportResult, error) {
+	return s.threatExporter.Export(ctx, w, format, filter)
+}
+
 // Close 关闭服务
 func (s *SecurityService) Close() {
+	// 保存异常检测模型状态
+	if err := s.SaveAnomalyModel(); err != nil {
+		log.Printf("保存异常检测模型失败: %v", err)
+	}
+
+	// 保存内存中未写入的用户行为画像
+	if err := s.behaviorProfile.Flush(context.Background()); err != nil {
+		log.Printf("保存用户行为画像失败: %v", err)
+	}
+
 	// 关闭威胁检测服务
 	if s.threatDetection != nil {
 		s.threatDetection.Close()
@@ -0,0 +1,262 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// managedServer 服务器管理器中的一个监听
+type managedServer struct {
+	name     string
+	server   *http.Server
+	listener net.Listener
+}
+
+// ServerManager 多端口服务器管理器
+// 功能说明：
+// 1. 管理API、HTTP重定向、管理、指标和仪表板等多个监听，每个监听使用独立的处理器和TLS设置
+// 2. Start 先监听所有端口再开始提供服务，任何端口监听失败时关闭已监听的端口并返回错误，不会只启动一部分
+// 3. 服务过程中的错误通过 Errors 通知，由调用方决定是否关闭
+// 4. Shutdown 在同一截止时间内并行优雅关闭所有监听，超时后强制关闭未完成的连接
+type ServerManager struct {
+	mu      sync.Mutex
+	servers []*managedServer
+	errors  chan error
+	started bool
+}
+
+// NewServerManager 创建服务器管理器
+func NewServerManager() *ServerManager {
+	return &ServerManager{errors: make(chan error, 8)}
+}
+
+// Add 添加监听，server.TLSConfig 不为空时提供HTTPS，需要在 Start 前调用
+func (m *ServerManager) Add(name string, server *http.Server) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.servers = append(m.servers, &managedServer{name: name, server: server})
+}
+
+// Start 监听所有端口并在后台提供服务
+func (m *ServerManager) Start() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.started {
+		return fmt.Errorf("服务器已启动")
+	}
+
+	for i, s := range m.servers {
+		listener, err := net.Listen("tcp", s.server.Addr)
+		if err != nil {
+			for _, opened := range m.servers[:i] {
+				opened.listener.Close()
+				opened.listener = nil
+			}
+			return fmt.Errorf("%s 监听 %s 失败: %v", s.name, s.server.Addr, err)
+		}
+		if s.server.TLSConfig != nil {
+			// ALPN包含h2时 net/http 在 Serve 中启用HTTP/2
+			listener = tls.NewListener(listener, s.server.TLSConfig)
+		}
+		s.listener = listener
+	}
+	m.started = true
+
+	for _, s := range m.servers {
+		go func(s *managedServer) {
+			log.Printf("%s server starting on %s (TLS: %v)", s.name, s.listener.Addr(), s.server.TLSConfig != nil)
+			if err := s.server.Serve(s.listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				select {
+				case m.errors <- fmt.Errorf("%s 服务错误: %v", s.name, err):
+				default:
+					log.Printf("%s server error: %v", s.name, err)
+				}
+			}
+		}(s)
+	}
+	return nil
+}
+
+// Errors 服务过程中的错误，如监听被意外关闭
+func (m *ServerManager) Errors() <-chan error {
+	return m.errors
+}
+
+// Addr 返回监听的实际地址，未启动或不存在时为 nil
+func (m *ServerManager) Addr(name string) net.Addr {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.servers {
+		if s.name == name && s.listener != nil {
+			return s.listener.Addr()
+		}
+	}
+	return nil
+}
+
+// Names 返回已添加的监听名称
+func (m *ServerManager) Names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.servers))
+	for _, s := range m.servers {
+		names = append(names, s.name)
+	}
+	return names
+}
+
+// Shutdown 并行优雅关闭所有监听，ctx 到期时强制关闭剩余连接
+func (m *ServerManager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	servers := m.servers
+	started := m.started
+	m.started = false
+	m.mu.Unlock()
+	if !started {
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(servers))
+	for i, s := range servers {
+		wg.Add(1)
+		go func(i int, s *managedServer) {
+			defer wg.Done()
+			if err := s.server.Shutdown(ctx); err != nil {
+				s.server.Close()
+				errs[i] = fmt.Errorf("%s 关闭失败: %v", s.name, err)
+			}
+		}(i, s)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// ListenerTLSConfig 创建内部监听的TLS配置
+// 功能说明：
+// 1. 配置了独立证书时使用独立证书（自动重新加载），协议版本、加密套件和ALPN与API端口相同
+// 2. 未配置独立证书时复用API端口的TLS配置
+// 3. 返回的重新加载器不为 nil 时需要调用 Watch 和 Close
+func ListenerTLSConfig(listener *Config.ListenerConfig, apiTLS *ServerTLS, tlsConfig *Config.ServerTLSConfig) (*tls.Config, *CertificateReloader, error) {
+	if !listener.TLS {
+		return nil, nil, nil
+	}
+	if listener.CertFile == "" {
+		if apiTLS == nil {
+			return nil, nil, fmt.Errorf("未配置证书，API端口也未启用TLS")
+		}
+		return apiTLS.TLSConfig.Clone(), nil, nil
+	}
+
+	reloader, err := NewCertificateReloader(listener.CertFile, listener.KeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	var config *tls.Config
+	if apiTLS != nil {
+		config = apiTLS.TLSConfig.Clone()
+		// 不复用ACME的验证协议，内部端口只使用独立证书
+		config.NextProtos = withoutProto(config.NextProtos, "acme-tls/1")
+	} else {
+		minVersion, err := tlsConfig.Version()
+		if err != nil {
+			return nil, nil, err
+		}
+		suites, err := tlsConfig.CipherSuiteIDs()
+		if err != nil {
+			return nil, nil, err
+		}
+		config = &tls.Config{MinVersion: minVersion, CipherSuites: suites, NextProtos: []string{"http/1.1"}}
+		if tlsConfig.HTTP2 {
+			config.NextProtos = []string{"h2", "http/1.1"}
+		}
+	}
+	config.GetCertificate = reloader.GetCertificate
+	return config, reloader, nil
+}
+
+// withoutProto 移除ALPN协议
+func withoutProto(protos []string, remove string) []string {
+	result := make([]string, 0, len(protos))
+	for _, proto := range protos {
+		if proto != remove {
+			result = append(result, proto)
+		}
+	}
+	return result
+}
+
+// ListenerGuard 内部监听的访问控制，来源IP不在白名单中返回403，缺少或令牌错误返回401
+// 来源IP取连接地址，不信任 X-Forwarded-For，内部端口不应该经过公网代理
+func ListenerGuard(next http.Handler, networks []*net.IPNet, token string) http.Handler {
+	if len(networks) == 0 && token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(networks) > 0 && !remoteAllowed(r.RemoteAddr, networks) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		if token != "" {
+			provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="internal"`)
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// remoteAllowed 连接地址是否在白名单中
+func remoteAllowed(remoteAddr string, networks []*net.IPNet) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// RouteFilter 按路由前缀划分端口，include 为 true 时只处理这些前缀，为 false 时这些前缀返回404
+// 前缀按路径段匹配，/api/v1/admin 匹配 /api/v1/admin/users，不匹配 /api/v1/administrators
+func RouteFilter(next http.Handler, prefixes []string, include bool) http.Handler {
+	if len(prefixes) == 0 && !include {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if routeMatches(r.URL.Path, prefixes) != include {
+			http.NotFound(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// routeMatches 路径是否匹配任一前缀
+func routeMatches(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
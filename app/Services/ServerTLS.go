@@ -0,0 +1,248 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// certificateReloadDelay 文件变化后等待的时间，证书和私钥通常先后写入，合并为一次重新加载
+const certificateReloadDelay = 500 * time.Millisecond
+
+// CertificateReloader 证书文件自动重新加载
+type CertificateReloader struct {
+	certFile string
+	keyFile  string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	loadedAt time.Time
+
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+}
+
+// NewCertificateReloader 加载证书并创建重新加载器
+// 功能说明：
+// 1. TLS握手通过 GetCertificate 获取当前证书，重新加载后新连接立即使用新证书，已建立的连接不受影响
+// 2. Watch 监听证书和私钥所在目录，文件被替换（包括Kubernetes Secret通过 ..data 符号链接切换）后重新加载
+// 3. 重新加载失败（如证书和私钥不匹配、只写入了一半）时保留旧证书并记录日志
+func NewCertificateReloader(certFile, keyFile string) (*CertificateReloader, error) {
+	r := &CertificateReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload 重新加载证书，失败时保留旧证书
+func (r *CertificateReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("加载证书失败: %v", err)
+	}
+	if cert.Leaf == nil && len(cert.Certificate) > 0 {
+		if leaf, err := x509.ParseCertificate(cert.Certificate[0]); err == nil {
+			cert.Leaf = leaf
+		}
+	}
+	r.mu.Lock()
+	r.cert = &cert
+	r.loadedAt = time.Now()
+	r.mu.Unlock()
+	return nil
+}
+
+// GetCertificate 返回当前证书，用于 tls.Config.GetCertificate
+func (r *CertificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// Certificate 返回当前证书和加载时间
+func (r *CertificateReloader) Certificate() (*tls.Certificate, time.Time) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, r.loadedAt
+}
+
+// Watch 监听证书文件变化并自动重新加载，Close 后停止
+func (r *CertificateReloader) Watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("创建证书文件监听失败: %v", err)
+	}
+	dirs := map[string]bool{filepath.Dir(r.certFile): true, filepath.Dir(r.keyFile): true}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return fmt.Errorf("监听证书目录 %s 失败: %v", dir, err)
+		}
+	}
+
+	r.mu.Lock()
+	r.watcher = watcher
+	r.done = make(chan struct{})
+	done := r.done
+	r.mu.Unlock()
+
+	go func() {
+		var timer *time.Timer
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+		for {
+			select {
+			case <-done:
+				return
+			case _, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				// 目录中的任何变化都可能替换证书（原子重命名、符号链接切换），延迟后统一重新加载
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(certificateReloadDelay, func() {
+					if err := r.Reload(); err != nil {
+						log.Printf("TLS证书重新加载失败，继续使用旧证书: %v", err)
+						return
+					}
+					log.Printf("TLS证书已重新加载: %s", r.certFile)
+				})
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("TLS证书文件监听错误: %v", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// Close 停止监听证书文件
+func (r *CertificateReloader) Close() error {
+	r.mu.Lock()
+	watcher, done := r.watcher, r.done
+	r.watcher, r.done = nil, nil
+	r.mu.Unlock()
+	if watcher == nil {
+		return nil
+	}
+	close(done)
+	return watcher.Close()
+}
+
+// ServerTLS API服务器的TLS设置
+type ServerTLS struct {
+	// TLSConfig HTTPS监听使用的TLS配置
+	TLSConfig *tls.Config
+	// HTTP2 是否通过ALPN协商HTTP/2
+	HTTP2 bool
+	// HTTPHandler HTTP端口的处理器（重定向到HTTPS，启用自动申请证书时同时处理HTTP-01验证），不需要监听HTTP端口时为 nil
+	HTTPHandler http.Handler
+	// Reloader 证书文件的重新加载器，自动申请证书时为 nil
+	Reloader *CertificateReloader
+}
+
+// NewServerTLS 按配置创建API服务器的TLS设置
+// 功能说明：
+// 1. 证书来自证书文件（自动重新加载）或ACME自动申请，两者只能选一个
+// 2. 设置最低协议版本、TLS 1.2加密套件和ALPN协议列表（h2、http/1.1）
+// 3. httpsPort 用于拼接重定向地址，为443时地址中省略端口
+func NewServerTLS(config *Config.ServerTLSConfig, httpsPort string) (*ServerTLS, error) {
+	if config == nil || !config.Enabled {
+		return nil, fmt.Errorf("TLS未启用")
+	}
+	minVersion, err := config.Version()
+	if err != nil {
+		return nil, err
+	}
+	suites, err := config.CipherSuiteIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:   minVersion,
+		CipherSuites: suites,
+		NextProtos:   []string{"http/1.1"},
+	}
+	if config.HTTP2 {
+		tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+	}
+	result := &ServerTLS{TLSConfig: tlsConfig, HTTP2: config.HTTP2}
+
+	var redirect http.Handler
+	if config.RedirectHTTP {
+		redirect = HTTPSRedirectHandler(httpsPort)
+	}
+
+	if config.Autocert {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(config.DomainList()...),
+			Cache:      autocert.DirCache(config.AutocertCacheDir),
+			Email:      config.AutocertEmail,
+		}
+		if config.AutocertDirectoryURL != "" {
+			manager.Client = &acme.Client{DirectoryURL: config.AutocertDirectoryURL}
+		}
+		tlsConfig.GetCertificate = manager.GetCertificate
+		tlsConfig.NextProtos = append(tlsConfig.NextProtos, acme.ALPNProto)
+		if redirect == nil {
+			// 未启用重定向时HTTP端口只处理HTTP-01验证，其他请求返回404
+			redirect = http.NotFoundHandler()
+		}
+		result.HTTPHandler = manager.HTTPHandler(redirect)
+		return result, nil
+	}
+
+	reloader, err := NewCertificateReloader(config.CertFile, config.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.GetCertificate = reloader.GetCertificate
+	result.Reloader = reloader
+	result.HTTPHandler = redirect
+	return result, nil
+}
+
+// HTTPSRedirectHandler 把HTTP请求重定向到HTTPS，GET和HEAD使用301，其他方法使用308保留请求方法和请求体
+func HTTPSRedirectHandler(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if host == "" {
+			http.Error(w, "缺少Host请求头", http.StatusBadRequest)
+			return
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		} else if net.ParseIP(host) != nil && net.ParseIP(host).To4() == nil {
+			host = "[" + host + "]"
+		}
+		status := http.StatusMovedPermanently
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			status = http.StatusPermanentRedirect
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), status)
+	})
+}
@@ -0,0 +1,585 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSlowQueryNotFound 慢查询统计不存在
+	ErrSlowQueryNotFound = errors.New("慢查询统计不存在")
+	// ErrExplainUnsupported 语句或数据库不支持 EXPLAIN
+	ErrExplainUnsupported = errors.New("只支持对MySQL、PostgreSQL和SQLite的SELECT语句执行EXPLAIN")
+)
+
+// slowQueryStartKey 记录SQL开始时间的实例键
+const slowQueryStartKey = "slow_query_analyzer:start"
+
+// slowQuerySampleLimit 完整SQL样本的最大长度
+const slowQuerySampleLimit = 8192
+
+// SlowQuerySortFields 慢查询统计支持的排序字段
+var SlowQuerySortFields = []string{"p95_time", "total_time", "max_time", "slow_count", "call_count", "last_seen_at"}
+
+// SlowQueryAnalyzer 查询性能分析器（GORM插件）
+// 功能说明：
+// 1. 通过GORM回调记录每条SQL的耗时，不依赖数据库的慢查询日志和processlist
+// 2. 按规范化后的SQL聚合调用次数、慢查询次数、累计/最大/P95耗时
+// 3. 出现过慢查询的语句定期写入 slow_query_stats 表，多实例部署时按增量累加
+// 4. 保存每条语句最慢一次执行的完整SQL，用于 EXPLAIN 分析
+//
+// 内存中最多跟踪 MaxRecords 条不同语句，超出后新语句不再跟踪。
+type SlowQueryAnalyzer struct {
+	config *Config.QueryOptimizationConfig
+	db     *gorm.DB
+
+	mu      sync.Mutex
+	queries map[string]*queryAggregate
+	dropped int64
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	isRunning bool
+}
+
+// queryAggregate 单条规范化语句的内存聚合
+//
+// calls/slow/total/max/rows/sample 是上次写库之后的增量，耗时样本跨写库保留。
+type queryAggregate struct {
+	normalized string
+	table      string
+	everSlow   bool
+
+	calls      int64
+	slow       int64
+	rows       int64
+	total      float64
+	max        float64
+	sample     string
+	sampleTime float64
+
+	samples   []float64
+	next      int
+	firstSeen time.Time
+	lastSeen  time.Time
+}
+
+// NewSlowQueryAnalyzer 创建查询性能分析器
+func NewSlowQueryAnalyzer(config *Config.QueryOptimizationConfig) *SlowQueryAnalyzer {
+	return &SlowQueryAnalyzer{
+		config:  config,
+		queries: make(map[string]*queryAggregate),
+	}
+}
+
+// Name 插件名称
+func (a *SlowQueryAnalyzer) Name() string {
+	return "slow_query_analyzer"
+}
+
+// Initialize 注册GORM回调
+func (a *SlowQueryAnalyzer) Initialize(db *gorm.DB) error {
+	a.db = db
+
+	before := func(db *gorm.DB) {
+		db.InstanceSet(slowQueryStartKey, time.Now())
+	}
+	callback := db.Callback()
+	errs := []error{
+		callback.Create().Before("gorm:create").Register("slow_query:before_create", before),
+		callback.Create().After("gorm:create").Register("slow_query:after_create", a.record),
+		callback.Query().Before("gorm:query").Register("slow_query:before_query", before),
+		callback.Query().After("gorm:query").Register("slow_query:after_query", a.record),
+		callback.Update().Before("gorm:update").Register("slow_query:before_update", before),
+		callback.Update().After("gorm:update").Register("slow_query:after_update", a.record),
+		callback.Delete().Before("gorm:delete").Register("slow_query:before_delete", before),
+		callback.Delete().After("gorm:delete").Register("slow_query:after_delete", a.record),
+		callback.Row().Before("gorm:row").Register("slow_query:before_row", before),
+		callback.Row().After("gorm:row").Register("slow_query:after_row", a.record),
+		callback.Raw().Before("gorm:raw").Register("slow_query:before_raw", before),
+		callback.Raw().After("gorm:raw").Register("slow_query:after_raw", a.record),
+	}
+	return errors.Join(errs...)
+}
+
+// Start 启动定期写库
+func (a *SlowQueryAnalyzer) Start() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.isRunning {
+		return fmt.Errorf("slow query analyzer is already running")
+	}
+	if a.db == nil {
+		return fmt.Errorf("slow query analyzer is not registered to a database")
+	}
+
+	a.ctx, a.cancel = context.WithCancel(context.Background())
+	go a.flushLoop(a.ctx)
+
+	a.isRunning = true
+	return nil
+}
+
+// Stop 停止定期写库并写入剩余统计
+func (a *SlowQueryAnalyzer) Stop() {
+	a.mu.Lock()
+	if !a.isRunning {
+		a.mu.Unlock()
+		return
+	}
+	a.cancel()
+	a.isRunning = false
+	a.mu.Unlock()
+
+	if err := a.Flush(context.Background()); err != nil {
+		log.Printf("写入慢查询统计失败: %v", err)
+	}
+}
+
+// flushLoop 定期写库
+func (a *SlowQueryAnalyzer) flushLoop(ctx context.Context) {
+	ticker := time.NewTicker(a.config.SlowQuery.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.Flush(ctx); err != nil {
+				log.Printf("写入慢查询统计失败: %v", err)
+			}
+		}
+	}
+}
+
+// record 记录一次SQL执行
+func (a *SlowQueryAnalyzer) record(db *gorm.DB) {
+	value, ok := db.InstanceGet(slowQueryStartKey)
+	if !ok {
+		return
+	}
+	start, ok := value.(time.Time)
+	if !ok {
+		return
+	}
+	duration := time.Since(start)
+
+	sql := db.Statement.SQL.String()
+	// 跳过统计表自身的读写，避免写库时递归统计
+	if sql == "" || db.Statement.Table == (Models.SlowQueryStat{}).TableName() {
+		return
+	}
+	normalized := NormalizeSQL(sql)
+	if strings.HasPrefix(strings.ToUpper(normalized), "EXPLAIN") {
+		return
+	}
+	fingerprint := SQLFingerprint(normalized)
+	elapsed := float64(duration) / float64(time.Millisecond)
+	isSlow := duration >= a.config.SlowQuery.Threshold
+	now := time.Now()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	aggregate, exists := a.queries[fingerprint]
+	if !exists {
+		if limit := a.config.SlowQuery.MaxRecords; limit > 0 && len(a.queries) >= limit {
+			a.dropped++
+			return
+		}
+		sampleSize := a.config.SlowQuery.SampleSize
+		if sampleSize <= 0 {
+			sampleSize = 500
+		}
+		aggregate = &queryAggregate{
+			normalized: normalized,
+			table:      db.Statement.Table,
+			samples:    make([]float64, 0, sampleSize),
+			firstSeen:  now,
+		}
+		a.queries[fingerprint] = aggregate
+	}
+
+	aggregate.calls++
+	aggregate.total += elapsed
+	aggregate.rows += db.RowsAffected
+	aggregate.lastSeen = now
+	if elapsed > aggregate.max {
+		aggregate.max = elapsed
+	}
+	if isSlow {
+		aggregate.slow++
+		aggregate.everSlow = true
+		if elapsed > aggregate.sampleTime {
+			aggregate.sampleTime = elapsed
+			aggregate.sample = truncateString(db.Dialector.Explain(sql, db.Statement.Vars...), slowQuerySampleLimit)
+		}
+	}
+
+	if len(aggregate.samples) < cap(aggregate.samples) {
+		aggregate.samples = append(aggregate.samples, elapsed)
+	} else {
+		aggregate.samples[aggregate.next] = elapsed
+		aggregate.next = (aggregate.next + 1) % len(aggregate.samples)
+	}
+}
+
+// slowQueryDelta 写库的增量快照
+type slowQueryDelta struct {
+	fingerprint string
+	aggregate   queryAggregate
+	p95         float64
+}
+
+// Flush 将出现过慢查询的语句增量写入 slow_query_stats 表
+func (a *SlowQueryAnalyzer) Flush(ctx context.Context) error {
+	if a.db == nil {
+		return nil
+	}
+
+	deltas := a.takeDeltas()
+	if len(deltas) == 0 {
+		return nil
+	}
+
+	db := a.db.Session(&gorm.Session{NewDB: true}).WithContext(ctx)
+	var failed []slowQueryDelta
+	var errs []error
+	for _, delta := range deltas {
+		if err := a.saveDelta(db, delta); err != nil {
+			failed = append(failed, delta)
+			errs = append(errs, err)
+		}
+	}
+	// 写库失败的增量放回内存，下次重试
+	a.restoreDeltas(failed)
+
+	if err := a.enforceRetention(db); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// takeDeltas 取出待写库的增量并清零
+func (a *SlowQueryAnalyzer) takeDeltas() []slowQueryDelta {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var deltas []slowQueryDelta
+	for fingerprint, aggregate := range a.queries {
+		if !aggregate.everSlow || aggregate.calls == 0 {
+			continue
+		}
+		delta := slowQueryDelta{fingerprint: fingerprint, aggregate: *aggregate, p95: percentile(aggregate.samples, 0.95)}
+		delta.aggregate.samples = nil
+		deltas = append(deltas, delta)
+
+		aggregate.calls, aggregate.slow, aggregate.rows = 0, 0, 0
+		aggregate.total, aggregate.max = 0, 0
+		aggregate.sample, aggregate.sampleTime = "", 0
+	}
+	return deltas
+}
+
+// restoreDeltas 将写库失败的增量合并回内存
+func (a *SlowQueryAnalyzer) restoreDeltas(deltas []slowQueryDelta) {
+	if len(deltas) == 0 {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, delta := range deltas {
+		aggregate, ok := a.queries[delta.fingerprint]
+		if !ok {
+			continue
+		}
+		aggregate.calls += delta.aggregate.calls
+		aggregate.slow += delta.aggregate.slow
+		aggregate.rows += delta.aggregate.rows
+		aggregate.total += delta.aggregate.total
+		if delta.aggregate.max > aggregate.max {
+			aggregate.max = delta.aggregate.max
+		}
+		if delta.aggregate.sampleTime > aggregate.sampleTime {
+			aggregate.sample, aggregate.sampleTime = delta.aggregate.sample, delta.aggregate.sampleTime
+		}
+	}
+}
+
+// saveDelta 累加单条语句的增量
+func (a *SlowQueryAnalyzer) saveDelta(db *gorm.DB, delta slowQueryDelta) error {
+	aggregate := delta.aggregate
+
+	var stat Models.SlowQueryStat
+	err := db.Where("fingerprint = ?", delta.fingerprint).Take(&stat).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return db.Create(&Models.SlowQueryStat{
+			Fingerprint:   delta.fingerprint,
+			NormalizedSQL: aggregate.normalized,
+			SampleSQL:     aggregate.sample,
+			Table:         aggregate.table,
+			CallCount:     aggregate.calls,
+			SlowCount:     aggregate.slow,
+			TotalTime:     aggregate.total,
+			MaxTime:       aggregate.max,
+			P95Time:       delta.p95,
+			RowsAffected:  aggregate.rows,
+			FirstSeenAt:   aggregate.firstSeen,
+			LastSeenAt:    aggregate.lastSeen,
+		}).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{
+		"call_count":    gorm.Expr("call_count + ?", aggregate.calls),
+		"slow_count":    gorm.Expr("slow_count + ?", aggregate.slow),
+		"total_time":    gorm.Expr("total_time + ?", aggregate.total),
+		"rows_affected": gorm.Expr("rows_affected + ?", aggregate.rows),
+		"p95_time":      delta.p95,
+		"last_seen_at":  aggregate.lastSeen,
+	}
+	if aggregate.max > stat.MaxTime {
+		updates["max_time"] = aggregate.max
+		if aggregate.sample != "" {
+			updates["sample_sql"] = aggregate.sample
+		}
+	}
+	return db.Model(&Models.SlowQueryStat{}).Where("id = ?", stat.ID).Updates(updates).Error
+}
+
+// enforceRetention 只保留累计耗时最高的 MaxRecords 条统计
+func (a *SlowQueryAnalyzer) enforceRetention(db *gorm.DB) error {
+	limit := a.config.SlowQuery.MaxRecords
+	if limit <= 0 {
+		return nil
+	}
+	var ids []uint
+	if err := db.Model(&Models.SlowQueryStat{}).Order("total_time DESC").Offset(limit).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return db.Where("id IN ?", ids).Delete(&Models.SlowQueryStat{}).Error
+}
+
+// TopSlowQueries 获取慢查询统计排行
+//
+// sortBy 为 SlowQuerySortFields 之一，默认按P95耗时；limit 默认 TopN。
+func (a *SlowQueryAnalyzer) TopSlowQueries(ctx context.Context, sortBy string, limit int) ([]Models.SlowQueryStat, error) {
+	if !containsString(SlowQuerySortFields, sortBy) {
+		sortBy = "p95_time"
+	}
+	if limit <= 0 {
+		limit = a.config.SlowQuery.TopN
+	}
+	if maxRecords := a.config.SlowQuery.MaxRecords; maxRecords > 0 && limit > maxRecords {
+		limit = maxRecords
+	}
+
+	var stats []Models.SlowQueryStat
+	err := a.db.Session(&gorm.Session{NewDB: true}).WithContext(ctx).
+		Where("slow_count > 0").
+		Order(sortBy + " DESC").
+		Limit(limit).
+		Find(&stats).Error
+	return stats, err
+}
+
+// GetSlowQuery 获取单条慢查询统计
+func (a *SlowQueryAnalyzer) GetSlowQuery(ctx context.Context, id uint) (*Models.SlowQueryStat, error) {
+	var stat Models.SlowQueryStat
+	err := a.db.Session(&gorm.Session{NewDB: true}).WithContext(ctx).Take(&stat, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSlowQueryNotFound
+	}
+	return &stat, err
+}
+
+// Explain 获取慢查询样本的执行计划
+//
+// 只对SELECT语句执行不带ANALYZE的EXPLAIN，不会真正执行语句。
+func (a *SlowQueryAnalyzer) Explain(ctx context.Context, id uint) ([]map[string]interface{}, error) {
+	stat, err := a.GetSlowQuery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	sql := strings.TrimRight(strings.TrimSpace(stat.SampleSQL), ";")
+	upper := strings.ToUpper(sql)
+	if sql == "" || strings.Contains(sql, ";") ||
+		!(strings.HasPrefix(upper, "SELECT") || strings.HasPrefix(upper, "WITH")) {
+		return nil, ErrExplainUnsupported
+	}
+
+	var explain string
+	switch a.db.Dialector.Name() {
+	case "mysql", "postgres":
+		explain = "EXPLAIN " + sql
+	case "sqlite":
+		explain = "EXPLAIN QUERY PLAN " + sql
+	default:
+		return nil, ErrExplainUnsupported
+	}
+
+	var rows []map[string]interface{}
+	if err := a.db.Session(&gorm.Session{NewDB: true}).WithContext(ctx).Raw(explain).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	// MySQL驱动返回[]byte，转换为字符串便于JSON输出
+	for _, row := range rows {
+		for key, value := range row {
+			if b, ok := value.([]byte); ok {
+				row[key] = string(b)
+			}
+		}
+	}
+	return rows, nil
+}
+
+// Dropped 因超出 MaxRecords 未跟踪的执行次数
+func (a *SlowQueryAnalyzer) Dropped() int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.dropped
+}
+
+var (
+	sqlInListPattern    = regexp.MustCompile(`(?i)\bIN\s*\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	sqlValuesRowPattern = regexp.MustCompile(`(\(\s*\?(?:\s*,\s*\?)*\s*\))(?:\s*,\s*\(\s*\?(?:\s*,\s*\?)*\s*\))+`)
+)
+
+// NormalizeSQL 规范化SQL
+// 功能说明：
+// 1. 字符串、数字字面量和 $N 占位符替换为 ?
+// 2. 去掉注释，连续空白合并为一个空格
+// 3. IN (?, ?, ?) 合并为 IN (?)，批量 VALUES 合并为一组
+//
+// 参数不同但结构相同的语句规范化后相同，用于聚合统计。
+func NormalizeSQL(sql string) string {
+	var builder strings.Builder
+	builder.Grow(len(sql))
+
+	space := false
+	writeSpace := func() {
+		if space && builder.Len() > 0 {
+			builder.WriteByte(' ')
+		}
+		space = false
+	}
+
+	for i := 0; i < len(sql); i++ {
+		ch := sql[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			space = true
+		case ch == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+			space = true
+		case ch == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 3
+			}
+			space = true
+		case ch == '\'':
+			// 字符串字面量，支持 '' 和 \' 转义
+			for i++; i < len(sql); i++ {
+				if sql[i] == '\\' {
+					i++
+				} else if sql[i] == '\'' {
+					if i+1 < len(sql) && sql[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			writeSpace()
+			builder.WriteByte('?')
+		case ch == '$' && i+1 < len(sql) && isSQLDigit(sql[i+1]):
+			for i+1 < len(sql) && isSQLDigit(sql[i+1]) {
+				i++
+			}
+			writeSpace()
+			builder.WriteByte('?')
+		case isSQLDigit(ch) && (i == 0 || !isSQLIdentChar(sql[i-1])):
+			for i+1 < len(sql) && (isSQLDigit(sql[i+1]) || sql[i+1] == '.') {
+				i++
+			}
+			writeSpace()
+			builder.WriteByte('?')
+		case ch == '"' || ch == '`':
+			// 引号标识符原样保留
+			end := strings.IndexByte(sql[i+1:], ch)
+			writeSpace()
+			if end < 0 {
+				builder.WriteString(sql[i:])
+				i = len(sql)
+			} else {
+				builder.WriteString(sql[i : i+end+2])
+				i += end + 1
+			}
+		default:
+			writeSpace()
+			builder.WriteByte(ch)
+		}
+	}
+
+	normalized := sqlInListPattern.ReplaceAllString(builder.String(), "IN (?)")
+	normalized = sqlValuesRowPattern.ReplaceAllString(normalized, "$1")
+	return strings.TrimSpace(normalized)
+}
+
+// SQLFingerprint 计算规范化SQL的指纹
+func SQLFingerprint(normalized string) string {
+	sum := sha1.Sum([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+func isSQLDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isSQLIdentChar(ch byte) bool {
+	return ch == '_' || isSQLDigit(ch) || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+// percentile 计算分位数
+func percentile(samples []float64, p float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+	index := int(float64(len(sorted))*p+0.5) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(sorted) {
+		index = len(sorted) - 1
+	}
+	return sorted[index]
+}
@@ -0,0 +1,340 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 依赖状态
+const (
+	DependencyWaiting     = "waiting"     // 启动时等待就绪
+	DependencyReady       = "ready"       // 已就绪
+	DependencyUnavailable = "unavailable" // 等待超时或检查失败，后台继续重试
+)
+
+// 启动状态
+const (
+	StartupStateStarting = "starting" // 正在等待依赖
+	StartupStateReady    = "ready"    // 所有依赖已就绪
+	StartupStateDegraded = "degraded" // 部分依赖不可用，相关功能暂停
+)
+
+// StartupDependency 启动依赖
+type StartupDependency struct {
+	Name          string
+	Check         func(ctx context.Context) error
+	Features      []string // 依赖不可用时暂停的功能
+	RoutePrefixes []string // 依赖不可用时返回503的路由前缀
+	OnReady       func()   // 降级启动后依赖恢复时调用，如执行数据库迁移
+}
+
+// DependencyStatus 依赖状态，用于 /readyz
+type DependencyStatus struct {
+	Name             string     `json:"name"`
+	Critical         bool       `json:"critical"`
+	State            string     `json:"state"`
+	Attempts         int        `json:"attempts"`
+	LastError        string     `json:"last_error,omitempty"`
+	ReadyAt          *time.Time `json:"ready_at,omitempty"`
+	WaitDuration     string     `json:"wait_duration,omitempty"` // 从开始等待到就绪的时间
+	DisabledFeatures []string   `json:"disabled_features,omitempty"`
+}
+
+// startupDependencyState 依赖的检查状态
+type startupDependencyState struct {
+	StartupDependency
+	critical  bool
+	state     string
+	attempts  int
+	lastError string
+	startedAt time.Time
+	readyAt   *time.Time
+}
+
+// StartupOrchestrator 启动编排服务
+// 功能说明：
+// 1. 启动时并行检查各依赖，关键依赖按指数退避重试，直到就绪或超过最长等待时间
+// 2. 关键依赖超时未就绪时启动失败；启用降级模式时继续启动，依赖相关的路由返回503
+// 3. 非关键依赖只检查一次，不可用时直接降级
+// 4. 不可用的依赖在后台继续重试，恢复后调用 OnReady 并恢复相关功能
+// 5. 各依赖的状态、重试次数和最近的错误通过 /readyz 查看
+type StartupOrchestrator struct {
+	config       *Config.StartupConfig
+	mu           sync.RWMutex
+	dependencies []*startupDependencyState
+	now          func() time.Time
+	ctx          context.Context
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
+}
+
+// NewStartupOrchestrator 创建启动编排服务
+func NewStartupOrchestrator(config *Config.StartupConfig) *StartupOrchestrator {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &StartupOrchestrator{
+		config: config,
+		now:    time.Now,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Register 注册依赖，需在 Wait 之前调用
+func (o *StartupOrchestrator) Register(dependency StartupDependency) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.dependencies = append(o.dependencies, &startupDependencyState{
+		StartupDependency: dependency,
+		critical:          o.config.IsCritical(dependency.Name),
+		state:             DependencyWaiting,
+	})
+}
+
+// Wait 等待依赖就绪
+//
+// 关键依赖超过最长等待时间仍未就绪且未启用降级模式时返回错误；
+// 其他情况返回nil，未就绪的依赖标记为不可用并在后台重试。
+func (o *StartupOrchestrator) Wait(ctx context.Context) error {
+	if o.config.WaitEnabled {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.config.Timeout)
+		defer cancel()
+	}
+
+	o.mu.Lock()
+	dependencies := append([]*startupDependencyState(nil), o.dependencies...)
+	for _, dependency := range dependencies {
+		dependency.startedAt = o.now()
+	}
+	o.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, dependency := range dependencies {
+		wg.Add(1)
+		go func(dependency *startupDependencyState) {
+			defer wg.Done()
+			o.waitFor(ctx, dependency)
+		}(dependency)
+	}
+	wg.Wait()
+
+	o.mu.Lock()
+	var failed, unavailable []*startupDependencyState
+	for _, dependency := range dependencies {
+		if dependency.state == DependencyReady {
+			continue
+		}
+		dependency.state = DependencyUnavailable
+		if dependency.critical && !o.config.DegradedMode {
+			failed = append(failed, dependency)
+		}
+		unavailable = append(unavailable, dependency)
+	}
+	o.mu.Unlock()
+
+	if len(failed) > 0 {
+		messages := make([]string, 0, len(failed))
+		for _, dependency := range failed {
+			messages = append(messages, fmt.Sprintf("%s（%s）", dependency.Name, dependency.lastError))
+		}
+		return fmt.Errorf("关键依赖未就绪: %s", strings.Join(messages, "; "))
+	}
+
+	for _, dependency := range unavailable {
+		log.Printf("依赖 %s 不可用，以降级模式启动，暂停功能: %v", dependency.Name, dependency.Features)
+		o.wg.Add(1)
+		go o.retry(dependency)
+	}
+	return nil
+}
+
+// waitFor 检查依赖，关键依赖在等待时间内按指数退避重试
+func (o *StartupOrchestrator) waitFor(ctx context.Context, dependency *startupDependencyState) {
+	backoff := o.config.InitialBackoff
+	for {
+		if o.check(ctx, dependency) {
+			o.markReady(dependency)
+			return
+		}
+		if !o.config.WaitEnabled || !dependency.critical {
+			return
+		}
+		log.Printf("等待依赖 %s 就绪，%v 后重试", dependency.Name, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = nextStartupBackoff(backoff, o.config.MaxBackoff)
+	}
+}
+
+// retry 后台重试不可用的依赖，恢复后调用 OnReady
+func (o *StartupOrchestrator) retry(dependency *startupDependencyState) {
+	defer o.wg.Done()
+	backoff := o.config.InitialBackoff
+	for {
+		select {
+		case <-o.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if o.check(o.ctx, dependency) {
+			// OnReady 完成后才恢复相关路由，如数据库迁移完成后再接受请求
+			if dependency.OnReady != nil {
+				dependency.OnReady()
+			}
+			o.markReady(dependency)
+			log.Printf("依赖 %s 已恢复，恢复功能: %v", dependency.Name, dependency.Features)
+			return
+		}
+		backoff = nextStartupBackoff(backoff, o.config.MaxBackoff)
+	}
+}
+
+// check 检查一次依赖，记录检查次数和错误
+func (o *StartupOrchestrator) check(ctx context.Context, dependency *startupDependencyState) bool {
+	checkCtx, cancel := context.WithTimeout(ctx, o.config.CheckTimeout)
+	err := dependency.Check(checkCtx)
+	cancel()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	dependency.attempts++
+	if err != nil {
+		dependency.lastError = err.Error()
+		return false
+	}
+	dependency.lastError = ""
+	return true
+}
+
+// markReady 标记依赖已就绪
+func (o *StartupOrchestrator) markReady(dependency *startupDependencyState) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	now := o.now()
+	dependency.state = DependencyReady
+	dependency.readyAt = &now
+}
+
+// nextStartupBackoff 重试间隔翻倍，不超过最大间隔
+func nextStartupBackoff(current, max time.Duration) time.Duration {
+	if current *= 2; current > max {
+		return max
+	}
+	return current
+}
+
+// Stop 停止后台重试
+func (o *StartupOrchestrator) Stop() {
+	o.cancel()
+	o.wg.Wait()
+}
+
+// State 获取启动状态
+func (o *StartupOrchestrator) State() string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	state := StartupStateReady
+	for _, dependency := range o.dependencies {
+		switch dependency.state {
+		case DependencyWaiting:
+			return StartupStateStarting
+		case DependencyUnavailable:
+			state = StartupStateDegraded
+		}
+	}
+	return state
+}
+
+// DependencyReady 检查依赖是否就绪，未注册的依赖视为就绪
+func (o *StartupOrchestrator) DependencyReady(name string) bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	for _, dependency := range o.dependencies {
+		if dependency.Name == name {
+			return dependency.state == DependencyReady
+		}
+	}
+	return true
+}
+
+// Statuses 获取各依赖的状态
+func (o *StartupOrchestrator) Statuses() []DependencyStatus {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	statuses := make([]DependencyStatus, 0, len(o.dependencies))
+	for _, dependency := range o.dependencies {
+		status := DependencyStatus{
+			Name:      dependency.Name,
+			Critical:  dependency.critical,
+			State:     dependency.state,
+			Attempts:  dependency.attempts,
+			LastError: dependency.lastError,
+			ReadyAt:   dependency.readyAt,
+		}
+		if dependency.readyAt != nil && !dependency.startedAt.IsZero() {
+			status.WaitDuration = dependency.readyAt.Sub(dependency.startedAt).Round(time.Millisecond).String()
+		}
+		if dependency.state != DependencyReady {
+			status.DisabledFeatures = dependency.Features
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// DisabledFeatures 获取因依赖不可用而暂停的功能
+func (o *StartupOrchestrator) DisabledFeatures() []string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	var features []string
+	for _, dependency := range o.dependencies {
+		if dependency.state != DependencyReady {
+			features = append(features, dependency.Features...)
+		}
+	}
+	return features
+}
+
+// BlockedBy 检查请求路径是否因依赖不可用而暂停，返回不可用的依赖名称
+func (o *StartupOrchestrator) BlockedBy(path string) (string, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	for _, dependency := range o.dependencies {
+		if dependency.state == DependencyReady {
+			continue
+		}
+		for _, prefix := range dependency.RoutePrefixes {
+			if path == prefix || strings.HasPrefix(path, strings.TrimRight(prefix, "/")+"/") {
+				return dependency.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+var (
+	defaultStartupOrchestrator   *StartupOrchestrator
+	defaultStartupOrchestratorMu sync.RWMutex
+)
+
+// SetDefaultStartupOrchestrator 设置全局启动编排服务
+func SetDefaultStartupOrchestrator(orchestrator *StartupOrchestrator) {
+	defaultStartupOrchestratorMu.Lock()
+	defer defaultStartupOrchestratorMu.Unlock()
+	defaultStartupOrchestrator = orchestrator
+}
+
+// DefaultStartupOrchestrator 获取全局启动编排服务，未设置时返回 nil
+func DefaultStartupOrchestrator() *StartupOrchestrator {
+	defaultStartupOrchestratorMu.RLock()
+	defer defaultStartupOrchestratorMu.RUnlock()
+	return defaultStartupOrchestrator
+}
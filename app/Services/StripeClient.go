@@ -0,0 +1,302 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stripeDependency Stripe 在出站HTTP客户端中的依赖名称
+const stripeDependency = "stripe"
+
+// ErrInvalidStripeSignature Stripe Webhook 签名无效或已过期
+var ErrInvalidStripeSignature = errors.New("Stripe Webhook 签名无效")
+
+// StripeSubscription Stripe 订阅中需要同步的字段
+type StripeSubscription struct {
+	ID                string
+	CustomerID        string
+	Status            string
+	ItemID            string // 第一个订阅项ID，套餐订阅只有一个订阅项
+	PriceID           string
+	CurrentPeriodEnd  time.Time
+	CancelAtPeriodEnd bool
+}
+
+// BillingProrationPreview 更换套餐的按比例计费预览，金额为最小货币单位（如分）
+type BillingProrationPreview struct {
+	Plan            string    `json:"plan"`             // 目标套餐代码
+	Currency        string    `json:"currency"`         // 货币，如 usd
+	AmountDue       int64     `json:"amount_due"`       // 更换后下一张账单的应付金额
+	ProrationAmount int64     `json:"proration_amount"` // 其中按比例计费项的合计，降级时为负数
+	ProrationDate   time.Time `json:"proration_date"`   // 按比例计费的时间点，更换套餐时传回以保证金额一致
+}
+
+// StripeClient 计费服务使用的 Stripe API
+type StripeClient interface {
+	// CreateCustomer 创建客户，返回客户ID
+	CreateCustomer(ctx context.Context, email string, userID uint) (string, error)
+	// CreateCheckoutSession 创建订阅支付页面，返回支付页面地址
+	CreateCheckoutSession(ctx context.Context, customerID, priceID string, userID uint) (string, error)
+	// UpdateSubscriptionPrice 将订阅项更换为新价格
+	UpdateSubscriptionPrice(ctx context.Context, subscriptionID, itemID, priceID, proration string, prorationDate time.Time) (*StripeSubscription, error)
+	// PreviewSubscriptionPrice 预览更换价格后的账单
+	PreviewSubscriptionPrice(ctx context.Context, customerID, subscriptionID, itemID, priceID, proration string, prorationDate time.Time) (*BillingProrationPreview, error)
+	// CancelSubscription 在当前计费周期结束时取消订阅
+	CancelSubscription(ctx context.Context, subscriptionID string) (*StripeSubscription, error)
+}
+
+// stripeHTTPClient 通过 Stripe REST API 调用的客户端
+type stripeHTTPClient struct {
+	secretKey  string
+	endpoint   string
+	successURL string
+	cancelURL  string
+	client     *OutboundHTTPClient
+}
+
+// NewStripeClient 创建 Stripe 客户端
+//
+// client 为 nil 时使用全局出站HTTP客户端。写操作携带幂等键，出站客户端重试时 Stripe 不会重复执行。
+func NewStripeClient(config *Config.BillingConfig, client *OutboundHTTPClient) StripeClient {
+	if client == nil {
+		client = GetOutboundHTTPClient()
+	}
+	return &stripeHTTPClient{
+		secretKey:  config.StripeSecretKey,
+		endpoint:   mailEndpoint(config.StripeEndpoint, "https://api.stripe.com"),
+		successURL: config.CheckoutSuccessURL,
+		cancelURL:  config.CheckoutCancelURL,
+		client:     client,
+	}
+}
+
+func (s *stripeHTTPClient) CreateCustomer(ctx context.Context, email string, userID uint) (string, error) {
+	form := url.Values{}
+	form.Set("email", email)
+	form.Set("metadata[user_id]", strconv.FormatUint(uint64(userID), 10))
+	var customer struct {
+		ID string `json:"id"`
+	}
+	if err := s.post(ctx, "/v1/customers", form, &customer); err != nil {
+		return "", err
+	}
+	return customer.ID, nil
+}
+
+func (s *stripeHTTPClient) CreateCheckoutSession(ctx context.Context, customerID, priceID string, userID uint) (string, error) {
+	form := url.Values{}
+	form.Set("mode", "subscription")
+	form.Set("customer", customerID)
+	form.Set("line_items[0][price]", priceID)
+	form.Set("line_items[0][quantity]", "1")
+	form.Set("success_url", s.successURL)
+	form.Set("cancel_url", s.cancelURL)
+	form.Set("client_reference_id", strconv.FormatUint(uint64(userID), 10))
+	form.Set("subscription_data[metadata][user_id]", strconv.FormatUint(uint64(userID), 10))
+	var session struct {
+		URL string `json:"url"`
+	}
+	if err := s.post(ctx, "/v1/checkout/sessions", form, &session); err != nil {
+		return "", err
+	}
+	return session.URL, nil
+}
+
+func (s *stripeHTTPClient) UpdateSubscriptionPrice(ctx context.Context, subscriptionID, itemID, priceID, proration string, prorationDate time.Time) (*StripeSubscription, error) {
+	form := url.Values{}
+	form.Set("items[0][id]", itemID)
+	form.Set("items[0][price]", priceID)
+	form.Set("proration_behavior", proration)
+	if proration != Config.BillingProrationNone {
+		form.Set("proration_date", strconv.FormatInt(prorationDate.Unix(), 10))
+	}
+	var subscription stripeSubscriptionObject
+	if err := s.post(ctx, "/v1/subscriptions/"+url.PathEscape(subscriptionID), form, &subscription); err != nil {
+		return nil, err
+	}
+	return subscription.toSubscription(), nil
+}
+
+func (s *stripeHTTPClient) PreviewSubscriptionPrice(ctx context.Context, customerID, subscriptionID, itemID, priceID, proration string, prorationDate time.Time) (*BillingProrationPreview, error) {
+	form := url.Values{}
+	form.Set("customer", customerID)
+	form.Set("subscription", subscriptionID)
+	form.Set("subscription_details[items][0][id]", itemID)
+	form.Set("subscription_details[items][0][price]", priceID)
+	form.Set("subscription_details[proration_behavior]", proration)
+	if proration != Config.BillingProrationNone {
+		form.Set("subscription_details[proration_date]", strconv.FormatInt(prorationDate.Unix(), 10))
+	}
+	var invoice struct {
+		Currency  string `json:"currency"`
+		AmountDue int64  `json:"amount_due"`
+		Lines     struct {
+			Data []struct {
+				Amount    int64 `json:"amount"`
+				Proration bool  `json:"proration"`
+				Parent    struct {
+					SubscriptionItemDetails struct {
+						Proration bool `json:"proration"`
+					} `json:"subscription_item_details"`
+				} `json:"parent"`
+			} `json:"data"`
+		} `json:"lines"`
+	}
+	if err := s.post(ctx, "/v1/invoices/create_preview", form, &invoice); err != nil {
+		return nil, err
+	}
+	preview := &BillingProrationPreview{Currency: invoice.Currency, AmountDue: invoice.AmountDue, ProrationDate: prorationDate}
+	for _, line := range invoice.Lines.Data {
+		// 旧版API在行项目上标记 proration，新版移到了 parent.subscription_item_details
+		if line.Proration || line.Parent.SubscriptionItemDetails.Proration {
+			preview.ProrationAmount += line.Amount
+		}
+	}
+	return preview, nil
+}
+
+func (s *stripeHTTPClient) CancelSubscription(ctx context.Context, subscriptionID string) (*StripeSubscription, error) {
+	form := url.Values{}
+	form.Set("cancel_at_period_end", "true")
+	var subscription stripeSubscriptionObject
+	if err := s.post(ctx, "/v1/subscriptions/"+url.PathEscape(subscriptionID), form, &subscription); err != nil {
+		return nil, err
+	}
+	return subscription.toSubscription(), nil
+}
+
+// post 发送表单请求并解析响应，非2xx响应返回 Stripe 的错误信息
+func (s *stripeHTTPClient) post(ctx context.Context, path string, form url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.secretKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	idempotencyKey := make([]byte, 16)
+	rand.Read(idempotencyKey)
+	req.Header.Set("Idempotency-Key", hex.EncodeToString(idempotencyKey))
+
+	resp, err := s.client.Do(stripeDependency, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var result struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.Unmarshal(data, &result)
+		if result.Error.Message == "" {
+			result.Error.Message = http.StatusText(resp.StatusCode)
+		}
+		return fmt.Errorf("Stripe请求失败（%d）: %s", resp.StatusCode, result.Error.Message)
+	}
+	return json.Unmarshal(data, out)
+}
+
+// stripeSubscriptionObject Stripe API 和 Webhook 中的订阅对象
+type stripeSubscriptionObject struct {
+	ID                string `json:"id"`
+	Customer          string `json:"customer"`
+	Status            string `json:"status"`
+	CancelAtPeriodEnd bool   `json:"cancel_at_period_end"`
+	CurrentPeriodEnd  int64  `json:"current_period_end"`
+	Items             struct {
+		Data []struct {
+			ID               string `json:"id"`
+			CurrentPeriodEnd int64  `json:"current_period_end"`
+			Price            struct {
+				ID string `json:"id"`
+			} `json:"price"`
+		} `json:"data"`
+	} `json:"items"`
+}
+
+func (o *stripeSubscriptionObject) toSubscription() *StripeSubscription {
+	subscription := &StripeSubscription{
+		ID:                o.ID,
+		CustomerID:        o.Customer,
+		Status:            o.Status,
+		CancelAtPeriodEnd: o.CancelAtPeriodEnd,
+	}
+	periodEnd := o.CurrentPeriodEnd
+	if len(o.Items.Data) > 0 {
+		item := o.Items.Data[0]
+		subscription.ItemID = item.ID
+		subscription.PriceID = item.Price.ID
+		// 新版API的计费周期在订阅项上
+		if periodEnd == 0 {
+			periodEnd = item.CurrentPeriodEnd
+		}
+	}
+	if periodEnd > 0 {
+		subscription.CurrentPeriodEnd = time.Unix(periodEnd, 0).UTC()
+	}
+	return subscription
+}
+
+// stripeEvent Stripe Webhook 事件
+type stripeEvent struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Created int64  `json:"created"`
+	Data    struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+// VerifyStripeSignature 校验 Stripe-Signature 请求头
+//
+// 签名为 HMAC-SHA256(密钥, "时间戳.请求体")，请求头中可能有多个 v1 签名（轮换密钥期间），任意一个匹配即可；
+// 时间戳与当前时间的偏差超过 tolerance 时视为重放。
+func VerifyStripeSignature(payload []byte, header, secret string, tolerance time.Duration, now time.Time) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidStripeSignature
+	}
+	if diff := now.Sub(time.Unix(seconds, 0)); diff > tolerance || diff < -tolerance {
+		return fmt.Errorf("%w：时间戳超出允许的偏差", ErrInvalidStripeSignature)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+	for _, signature := range signatures {
+		if actual, err := hex.DecodeString(signature); err == nil && hmac.Equal(actual, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidStripeSignature
+}
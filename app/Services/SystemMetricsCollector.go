@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"sort"
 	"sync"
 	"time"
 
@@ -316,73 +317,54 @@ func (c *SystemMetricsCollector) collectProcessMetrics() (map[string]interface{}
 	return metrics, nil
 }
 
+// systemMetricGroups 保存到数据库的系统指标分组，按写入顺序排列
+var systemMetricGroups = []string{"cpu", "memory", "disk", "network", "goruntime", "process"}
+
 // SaveMetrics 保存指标到数据库
+// 全部分组的指标合并为一次批量插入，避免每个指标一条INSERT
 func (c *SystemMetricsCollector) SaveMetrics(ctx context.Context, metrics map[string]interface{}) error {
-	// 保存CPU指标
-	if cpuMetrics, ok := metrics["cpu"].(map[string]interface{}); ok {
-		if err := c.saveMetricToDB(ctx, "system", "cpu", cpuMetrics); err != nil {
-			return fmt.Errorf("failed to save CPU metrics: %w", err)
+	now := time.Now()
+	rows := make([]Models.MonitoringMetric, 0)
+	for _, group := range systemMetricGroups {
+		if groupMetrics, ok := metrics[group].(map[string]interface{}); ok {
+			rows = append(rows, c.buildMetricRows("system", group, groupMetrics, now)...)
 		}
 	}
-
-	// 保存内存指标
-	if memMetrics, ok := metrics["memory"].(map[string]interface{}); ok {
-		if err := c.saveMetricToDB(ctx, "system", "memory", memMetrics); err != nil {
-			return fmt.Errorf("failed to save memory metrics: %w", err)
-		}
-	}
-
-	// 保存磁盘指标
-	if diskMetrics, ok := metrics["disk"].(map[string]interface{}); ok {
-		if err := c.saveMetricToDB(ctx, "system", "disk", diskMetrics); err != nil {
-			return fmt.Errorf("failed to save disk metrics: %w", err)
-		}
+	if len(rows) == 0 {
+		return nil
 	}
 
-	// 保存网络指标
-	if netMetrics, ok := metrics["network"].(map[string]interface{}); ok {
-		if err := c.saveMetricToDB(ctx, "system", "network", netMetrics); err != nil {
-			return fmt.Errorf("failed to save network metrics: %w", err)
-		}
+	if err := c.service.DB.(*gorm.DB).WithContext(ctx).CreateInBatches(rows, systemMetricInsertBatchSize).Error; err != nil {
+		return fmt.Errorf("failed to save system metrics: %w", err)
 	}
+	return nil
+}
 
-	// 保存Go运行时指标
-	if goMetrics, ok := metrics["goruntime"].(map[string]interface{}); ok {
-		if err := c.saveMetricToDB(ctx, "system", "goruntime", goMetrics); err != nil {
-			return fmt.Errorf("failed to save Go runtime metrics: %w", err)
-		}
-	}
+// systemMetricInsertBatchSize 单条批量INSERT的最大行数
+const systemMetricInsertBatchSize = 200
 
-	// 保存进程指标
-	if procMetrics, ok := metrics["process"].(map[string]interface{}); ok {
-		if err := c.saveMetricToDB(ctx, "system", "process", procMetrics); err != nil {
-			return fmt.Errorf("failed to save process metrics: %w", err)
-		}
+// buildMetricRows 把一组指标转换为数据库记录，键按字母顺序排列保证写入顺序稳定
+func (c *SystemMetricsCollector) buildMetricRows(metricType, metricName string, data map[string]interface{}, timestamp time.Time) []Models.MonitoringMetric {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
 
-	return nil
-}
-
-// saveMetricToDB 保存指标到数据库
-func (c *SystemMetricsCollector) saveMetricToDB(ctx context.Context, metricType, metricName string, data map[string]interface{}) error {
-	for key, value := range data {
-		metric := &Models.MonitoringMetric{
+	rows := make([]Models.MonitoringMetric, 0, len(keys))
+	for _, key := range keys {
+		rows = append(rows, Models.MonitoringMetric{
 			Type:        metricType,
 			Name:        fmt.Sprintf("%s_%s", metricName, key),
-			Value:       c.convertToFloat64(value),
+			Value:       c.convertToFloat64(data[key]),
 			Unit:        c.getUnit(key),
-			Timestamp:   time.Now(),
+			Timestamp:   timestamp,
 			Description: fmt.Sprintf("System metric: %s %s", metricName, key),
 			Status:      "normal",
 			Severity:    "info",
-		}
-
-		if err := c.service.DB.(*gorm.DB).WithContext(ctx).Create(metric).Error; err != nil {
-			return err
-		}
+		})
 	}
-
-	return nil
+	return rows
 }
 
 // convertToFloat64 转换为float64
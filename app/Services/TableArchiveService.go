@@ -0,0 +1,863 @@
+package Services
+
+import (
+	"bufio"
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// ErrArchiveTableNotSupported 表不在分区归档范围内
+var ErrArchiveTableNotSupported = errors.New("该表未启用分区归档")
+
+// ArchivedTable 可分区归档的表
+type ArchivedTable struct {
+	Name       string      // 表名
+	TimeColumn string      // 分区使用的时间列
+	Model      interface{} // 模型指针，导出和查询归档文件时按模型解析字段
+}
+
+// archivableTables 支持分区归档的表
+var archivableTables = []ArchivedTable{
+	{Name: "monitoring_metrics", TimeColumn: "timestamp", Model: &Models.MonitoringMetric{}},
+	{Name: "login_attempts", TimeColumn: "attempt_time", Model: &Models.LoginAttempt{}},
+	{Name: "security_events", TimeColumn: "created_at", Model: &Models.SecurityEvent{}},
+}
+
+// ArchiveQuery 跨在线表和归档文件的查询条件
+type ArchiveQuery struct {
+	From      time.Time              // 时间下界（包含），零值表示不限
+	To        time.Time              // 时间上界（不包含），零值表示不限
+	Where     map[string]interface{} // 按列相等过滤
+	Offset    int
+	Limit     int  // 小于等于0表示不限
+	Ascending bool // 默认按时间倒序
+}
+
+// ArchiveRunResult 一次分区维护的结果
+type ArchiveRunResult struct {
+	Created  []string `json:"created"`  // 新建的分区
+	Archived []string `json:"archived"` // 已归档并删除的分区
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// archiveSource 查询的一个数据来源：在线表、月表或归档文件
+type archiveSource struct {
+	name      string
+	partition *Models.TablePartition // 在线主表为空
+}
+
+// archiveCacheEntry 已解压的归档文件
+type archiveCacheEntry struct {
+	key  string
+	rows reflect.Value // 模型切片
+}
+
+// TableArchiveService 大表分区和归档服务
+// 功能说明：
+// 1. PostgreSQL首次维护时把表转换为按时间列范围分区的表，原有数据作为一个分区挂载，之后按月提前创建分区
+// 2. MySQL和SQLite每月把上月及更早的数据轮转到独立的月表（MySQL使用 RENAME TABLE 原子交换），在线表只保留当月数据
+// 3. 时间上界早于在线保留时间的分区导出为gzip压缩的JSON Lines文件，写入归档存储并校验后删除分区，不执行DELETE
+// 4. Query 按时间范围选择在线表、月表和归档文件，分区之间时间不重叠，按时间顺序依次读取并统一分页
+// 5. 分区记录保存在 table_partitions 表中
+type TableArchiveService struct {
+	db      *gorm.DB
+	config  *Config.ArchiveConfig
+	store   ArchiveObjectStore
+	tables  map[string]ArchivedTable
+	schemas sync.Map
+	now     func() time.Time
+
+	// runMu 保证同一时间只有一次分区维护
+	runMu sync.Mutex
+
+	cacheMu sync.Mutex
+	cache   []*archiveCacheEntry
+}
+
+// NewTableArchiveService 创建大表分区和归档服务
+func NewTableArchiveService(db *gorm.DB, config *Config.ArchiveConfig, store ArchiveObjectStore) *TableArchiveService {
+	if config == nil {
+		if globalConfig := Config.GetArchiveConfig(); globalConfig != nil {
+			config = globalConfig
+		} else {
+			config = &Config.ArchiveConfig{
+				Tables:        "monitoring_metrics,login_attempts,security_events",
+				HotRetention:  90 * 24 * time.Hour,
+				PremakeMonths: 2,
+				CheckInterval: 6 * time.Hour,
+				Store:         Config.ArchiveStoreLocal,
+				LocalPath:     "storage/archive",
+				CacheSize:     4,
+			}
+		}
+	}
+
+	tables := make(map[string]ArchivedTable)
+	for _, name := range config.TableNames() {
+		for _, table := range archivableTables {
+			if table.Name == name {
+				tables[name] = table
+			}
+		}
+	}
+
+	return &TableArchiveService{
+		db:     db,
+		config: config,
+		store:  store,
+		tables: tables,
+		now:    time.Now,
+	}
+}
+
+// SetClock 设置计算分区边界和保留时间使用的时钟
+func (s *TableArchiveService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// Tables 返回启用分区归档的表名
+func (s *TableArchiveService) Tables() []string {
+	names := make([]string, 0, len(s.tables))
+	for name := range s.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Partitioned 表是否启用了分区归档
+func (s *TableArchiveService) Partitioned(table string) bool {
+	_, ok := s.tables[table]
+	return ok
+}
+
+// Partitions 返回表的分区记录，table 为空时返回全部，按时间上界倒序
+func (s *TableArchiveService) Partitions(table string) ([]Models.TablePartition, error) {
+	var partitions []Models.TablePartition
+	query := s.db.Order("range_end DESC, id DESC")
+	if table != "" {
+		query = query.Where("table_name = ?", table)
+	}
+	err := query.Find(&partitions).Error
+	return partitions, err
+}
+
+// Start 按检查间隔在后台执行分区维护
+func (s *TableArchiveService) Start(ctx context.Context) {
+	go func() {
+		s.logRun(s.Run(ctx))
+		ticker := time.NewTicker(s.config.CheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.logRun(s.Run(ctx))
+			}
+		}
+	}()
+}
+
+// logRun 记录分区维护结果
+func (s *TableArchiveService) logRun(result *ArchiveRunResult, err error) {
+	if err != nil {
+		log.Printf("大表分区维护失败: %v", err)
+		return
+	}
+	if len(result.Created) > 0 || len(result.Archived) > 0 {
+		log.Printf("大表分区维护完成: created=%v, archived=%v", result.Created, result.Archived)
+	}
+	for _, message := range result.Errors {
+		log.Printf("大表分区维护出错: %s", message)
+	}
+}
+
+// Run 执行一次分区维护：创建或轮转分区，归档超过在线保留时间的分区
+// 单个表失败不影响其他表，错误记录在结果中
+func (s *TableArchiveService) Run(ctx context.Context) (*ArchiveRunResult, error) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	if err := s.db.WithContext(ctx).AutoMigrate(&Models.TablePartition{}); err != nil {
+		return nil, err
+	}
+
+	result := &ArchiveRunResult{Created: []string{}, Archived: []string{}}
+	now := s.now()
+	for _, name := range s.Tables() {
+		table := s.tables[name]
+		var (
+			created []string
+			err     error
+		)
+		if s.dialect() == "postgres" {
+			created, err = s.ensureNativePartitions(ctx, table, now)
+		} else {
+			created, err = s.rotateMonthlyTable(ctx, table, now)
+		}
+		result.Created = append(result.Created, created...)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+
+		archived, err := s.archiveDue(ctx, table, now)
+		result.Archived = append(result.Archived, archived...)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	return result, nil
+}
+
+// dialect 数据库类型：postgres、mysql、sqlite
+func (s *TableArchiveService) dialect() string {
+	return s.db.Dialector.Name()
+}
+
+// quote 按数据库方言引用标识符
+func (s *TableArchiveService) quote(name string) string {
+	return s.db.Statement.Quote(name)
+}
+
+// partitionName 分区表名，如 monitoring_metrics_p202401
+func partitionName(table string, month time.Time) string {
+	return fmt.Sprintf("%s_p%s", table, month.Format("200601"))
+}
+
+// monthStart 返回 t 所在月的第一天零点
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// sqlTime 分区边界的SQL字面量
+func sqlTime(t time.Time) string {
+	return "'" + t.Format("2006-01-02 15:04:05") + "'"
+}
+
+// ensureNativePartitions PostgreSQL原生分区：未分区的表先转换，再按月创建当前及之后 PremakeMonths 个月的分区
+func (s *TableArchiveService) ensureNativePartitions(ctx context.Context, table ArchivedTable, now time.Time) ([]string, error) {
+	db := s.db.WithContext(ctx)
+	created := make([]string, 0)
+
+	var partitioned int64
+	if err := db.Raw(`SELECT COUNT(*) FROM pg_partitioned_table pt JOIN pg_class c ON c.oid = pt.partrelid WHERE c.relname = ?`, table.Name).
+		Scan(&partitioned).Error; err != nil {
+		return nil, err
+	}
+	if partitioned == 0 {
+		legacy, err := s.convertToNativePartitions(ctx, table, now)
+		if err != nil {
+			return nil, fmt.Errorf("转换为分区表失败: %w", err)
+		}
+		created = append(created, legacy)
+	}
+
+	// 从已有分区的上界开始创建，避免与转换时挂载的原有数据分区重叠
+	start := monthStart(now)
+	var latest Models.TablePartition
+	if err := db.Where("table_name = ?", table.Name).Order("range_end DESC").Limit(1).Find(&latest).Error; err != nil {
+		return created, err
+	}
+	if latest.ID != 0 && latest.RangeEnd.After(start) {
+		start = latest.RangeEnd
+	}
+
+	end := monthStart(now).AddDate(0, s.config.PremakeMonths+1, 0)
+	for month := start; month.Before(end); month = month.AddDate(0, 1, 0) {
+		name := partitionName(table.Name, month)
+		next := month.AddDate(0, 1, 0)
+		statement := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM (%s) TO (%s)",
+			s.quote(name), s.quote(table.Name), sqlTime(month), sqlTime(next))
+		if err := db.Exec(statement).Error; err != nil {
+			return created, err
+		}
+		if err := s.savePartition(ctx, &Models.TablePartition{
+			Table: table.Name, PartitionName: name, Strategy: Models.TablePartitionNative,
+			RangeStart: month, RangeEnd: next, Status: Models.TablePartitionLive,
+		}); err != nil {
+			return created, err
+		}
+		created = append(created, name)
+	}
+	return created, nil
+}
+
+// convertToNativePartitions 把普通表转换为分区表，原有数据整体挂载为 [最早时间, 下月初) 的分区
+// 自增序列改为由新表拥有，归档删除原有数据分区时不会删除序列
+func (s *TableArchiveService) convertToNativePartitions(ctx context.Context, table ArchivedTable, now time.Time) (string, error) {
+	legacy := table.Name + "_p_legacy"
+	boundary := monthStart(now).AddDate(0, 1, 0)
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var sequence *string
+		if err := tx.Raw("SELECT pg_get_serial_sequence(?, 'id')", table.Name).Scan(&sequence).Error; err != nil {
+			return err
+		}
+		statements := []string{
+			fmt.Sprintf("ALTER TABLE %s RENAME TO %s", s.quote(table.Name), s.quote(legacy)),
+			fmt.Sprintf("CREATE TABLE %s (LIKE %s INCLUDING DEFAULTS INCLUDING CONSTRAINTS) PARTITION BY RANGE (%s)",
+				s.quote(table.Name), s.quote(legacy), s.quote(table.TimeColumn)),
+			fmt.Sprintf("ALTER TABLE %s ATTACH PARTITION %s FOR VALUES FROM (MINVALUE) TO (%s)",
+				s.quote(table.Name), s.quote(legacy), sqlTime(boundary)),
+			fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s)",
+				s.quote(table.Name+"_"+table.TimeColumn+"_part_idx"), s.quote(table.Name), s.quote(table.TimeColumn)),
+		}
+		if sequence != nil && *sequence != "" {
+			statements = append(statements, fmt.Sprintf("ALTER SEQUENCE %s OWNED BY %s.id", *sequence, s.quote(table.Name)))
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	rangeStart, err := s.minTime(ctx, legacy, table.TimeColumn, boundary)
+	if err != nil {
+		return "", err
+	}
+	return legacy, s.savePartition(ctx, &Models.TablePartition{
+		Table: table.Name, PartitionName: legacy, Strategy: Models.TablePartitionNative,
+		RangeStart: rangeStart, RangeEnd: boundary, Status: Models.TablePartitionLive,
+	})
+}
+
+// rotateMonthlyTable MySQL和SQLite按月轮转：在线表中早于本月的数据移入月表
+func (s *TableArchiveService) rotateMonthlyTable(ctx context.Context, table ArchivedTable, now time.Time) ([]string, error) {
+	cutoff := monthStart(now)
+	var older int64
+	if err := s.db.WithContext(ctx).Table(table.Name).Where(s.quote(table.TimeColumn)+" < ?", cutoff).Count(&older).Error; err != nil {
+		return nil, err
+	}
+	if older == 0 {
+		return nil, nil
+	}
+
+	rangeStart, err := s.minTime(ctx, table.Name, table.TimeColumn, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	// 轮转后仍有延迟写入的旧数据时，使用带时间戳后缀的新月表，不改动已有月表
+	name := partitionName(table.Name, cutoff.AddDate(0, -1, 0))
+	var existing int64
+	if err := s.db.WithContext(ctx).Model(&Models.TablePartition{}).Where("partition_name = ?", name).Count(&existing).Error; err != nil {
+		return nil, err
+	}
+	if existing > 0 || s.db.Migrator().HasTable(name) {
+		name = fmt.Sprintf("%s_%d", name, now.Unix())
+	}
+
+	if s.dialect() == "mysql" {
+		err = s.rotateMySQL(ctx, table, name, cutoff)
+	} else {
+		err = s.rotateByCopy(ctx, table, name, cutoff)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{name}, s.savePartition(ctx, &Models.TablePartition{
+		Table: table.Name, PartitionName: name, Strategy: Models.TablePartitionTable,
+		RangeStart: rangeStart, RangeEnd: cutoff, Status: Models.TablePartitionLive,
+	})
+}
+
+// rotateMySQL 使用 RENAME TABLE 原子交换在线表和空表，再把本月的数据移回在线表
+// 只移动本月的少量数据，避免对上月数据执行DELETE；交换后重置自增值，防止ID与月表重复
+func (s *TableArchiveService) rotateMySQL(ctx context.Context, table ArchivedTable, name string, cutoff time.Time) error {
+	db := s.db.WithContext(ctx)
+	staging := table.Name + "_rotate"
+	timeColumn := s.quote(table.TimeColumn)
+
+	var maxID int64
+	if err := db.Table(table.Name).Select("COALESCE(MAX(id), 0)").Scan(&maxID).Error; err != nil {
+		return err
+	}
+	statements := []string{
+		fmt.Sprintf("DROP TABLE IF EXISTS %s", s.quote(staging)),
+		fmt.Sprintf("CREATE TABLE %s LIKE %s", s.quote(staging), s.quote(table.Name)),
+		fmt.Sprintf("ALTER TABLE %s AUTO_INCREMENT = %d", s.quote(staging), maxID+1000),
+		fmt.Sprintf("RENAME TABLE %s TO %s, %s TO %s", s.quote(table.Name), s.quote(name), s.quote(staging), s.quote(table.Name)),
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(fmt.Sprintf("INSERT INTO %s SELECT * FROM %s WHERE %s >= ?", s.quote(table.Name), s.quote(name), timeColumn), cutoff).Error; err != nil {
+			return err
+		}
+		return tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s >= ?", s.quote(name), timeColumn), cutoff).Error
+	})
+}
+
+// rotateByCopy 按原表结构创建月表并复制早于本月的数据，用于不支持 RENAME 交换的SQLite（开发和测试环境）
+func (s *TableArchiveService) rotateByCopy(ctx context.Context, table ArchivedTable, name string, cutoff time.Time) error {
+	var createSQL string
+	if err := s.db.WithContext(ctx).Raw("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", table.Name).
+		Scan(&createSQL).Error; err != nil {
+		return err
+	}
+	// 保留原表的列类型，SQLite按声明类型解析时间列
+	index := strings.Index(createSQL, "(")
+	if index < 0 {
+		return fmt.Errorf("无法解析表结构: %s", table.Name)
+	}
+	createSQL = "CREATE TABLE " + s.quote(name) + " " + createSQL[index:]
+
+	timeColumn := s.quote(table.TimeColumn)
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(createSQL).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(fmt.Sprintf("INSERT INTO %s SELECT * FROM %s WHERE %s < ?", s.quote(name), s.quote(table.Name), timeColumn), cutoff).Error; err != nil {
+			return err
+		}
+		return tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s < ?", s.quote(table.Name), timeColumn), cutoff).Error
+	})
+}
+
+// minTime 返回表中早于 before 的最早时间，没有数据时返回 before
+func (s *TableArchiveService) minTime(ctx context.Context, tableName, column string, before time.Time) (time.Time, error) {
+	var raw interface{}
+	row := s.db.WithContext(ctx).Table(tableName).Select("MIN("+s.quote(column)+")").Where(s.quote(column)+" < ?", before).Row()
+	if err := row.Scan(&raw); err != nil {
+		return before, err
+	}
+	if earliest, ok := parseArchiveTime(raw); ok {
+		return earliest, nil
+	}
+	return before, nil
+}
+
+// parseArchiveTime 解析数据库返回的时间值，SQLite聚合函数返回文本
+func parseArchiveTime(raw interface{}) (time.Time, bool) {
+	switch value := raw.(type) {
+	case time.Time:
+		return value, true
+	case []byte:
+		return parseArchiveTime(string(value))
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05.999999999", "2006-01-02 15:04:05"} {
+			if parsed, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+				return parsed, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// savePartition 保存分区记录，同名分区已存在时不重复创建
+func (s *TableArchiveService) savePartition(ctx context.Context, partition *Models.TablePartition) error {
+	var existing int64
+	if err := s.db.WithContext(ctx).Model(&Models.TablePartition{}).Where("partition_name = ?", partition.PartitionName).Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return nil
+	}
+	return s.db.WithContext(ctx).Create(partition).Error
+}
+
+// archiveDue 归档时间上界早于在线保留时间的分区
+func (s *TableArchiveService) archiveDue(ctx context.Context, table ArchivedTable, now time.Time) ([]string, error) {
+	var due []Models.TablePartition
+	if err := s.db.WithContext(ctx).
+		Where("table_name = ? AND status = ? AND range_end <= ?", table.Name, Models.TablePartitionLive, now.Add(-s.config.HotRetention)).
+		Order("range_end ASC").Find(&due).Error; err != nil {
+		return nil, err
+	}
+
+	archived := make([]string, 0, len(due))
+	for i := range due {
+		if err := s.archivePartition(ctx, table, &due[i]); err != nil {
+			return archived, fmt.Errorf("归档分区 %s 失败: %w", due[i].PartitionName, err)
+		}
+		archived = append(archived, due[i].PartitionName)
+	}
+	return archived, nil
+}
+
+// archivePartition 导出分区数据，写入归档存储并读回校验，然后删除分区
+func (s *TableArchiveService) archivePartition(ctx context.Context, table ArchivedTable, partition *Models.TablePartition) error {
+	if s.store == nil {
+		return errors.New("归档存储未配置")
+	}
+
+	data, rows, err := s.exportPartition(ctx, table, partition.PartitionName)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+	key := ""
+	if rows > 0 {
+		key = fmt.Sprintf("%s/%s.jsonl.gz", table.Name, partition.PartitionName)
+		if err := s.store.Put(ctx, key, data); err != nil {
+			return err
+		}
+		stored, err := s.store.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		storedSum := sha256.Sum256(stored)
+		if hex.EncodeToString(storedSum[:]) != checksum {
+			return fmt.Errorf("归档文件校验失败: %s", key)
+		}
+	}
+
+	db := s.db.WithContext(ctx)
+	if partition.Strategy == Models.TablePartitionNative {
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE %s DETACH PARTITION %s", s.quote(table.Name), s.quote(partition.PartitionName))).Error; err != nil {
+			return err
+		}
+	}
+	if err := db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", s.quote(partition.PartitionName))).Error; err != nil {
+		return err
+	}
+
+	archivedAt := s.now()
+	return db.Model(partition).Updates(map[string]interface{}{
+		"status":       Models.TablePartitionArchived,
+		"row_count":    rows,
+		"archive_key":  key,
+		"archive_size": int64(len(data)),
+		"checksum":     checksum,
+		"archived_at":  &archivedAt,
+	}).Error
+}
+
+// exportPartition 按主键分批读取分区（包含软删除的记录），每行编码为一行JSON并gzip压缩
+func (s *TableArchiveService) exportPartition(ctx context.Context, table ArchivedTable, name string) ([]byte, int64, error) {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	encoder := json.NewEncoder(writer)
+
+	var rows int64
+	batch := reflect.New(reflect.SliceOf(reflect.TypeOf(table.Model).Elem()))
+	result := s.db.WithContext(ctx).Unscoped().Table(name).FindInBatches(batch.Interface(), 1000, func(tx *gorm.DB, _ int) error {
+		items := batch.Elem()
+		for i := 0; i < items.Len(); i++ {
+			if err := encoder.Encode(items.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		rows += int64(items.Len())
+		return nil
+	})
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+	if err := writer.Close(); err != nil {
+		return nil, 0, err
+	}
+	return buffer.Bytes(), rows, nil
+}
+
+// Query 按时间范围查询表数据，合并在线表、月表和归档文件的结果，返回符合条件的总数
+// dest 为模型切片指针，如 *[]Models.SecurityEvent
+func (s *TableArchiveService) Query(ctx context.Context, tableName string, query ArchiveQuery, dest interface{}) (int64, error) {
+	table, ok := s.tables[tableName]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrArchiveTableNotSupported, tableName)
+	}
+	destValue := reflect.ValueOf(dest)
+	modelType := reflect.TypeOf(table.Model).Elem()
+	if destValue.Kind() != reflect.Ptr || destValue.Elem().Kind() != reflect.Slice || destValue.Elem().Type().Elem() != modelType {
+		return 0, fmt.Errorf("查询结果类型必须为 *[]%s", modelType.Name())
+	}
+	sch, err := schema.Parse(table.Model, &s.schemas, s.db.NamingStrategy)
+	if err != nil {
+		return 0, err
+	}
+	for column := range query.Where {
+		if sch.LookUpField(column) == nil {
+			return 0, fmt.Errorf("未知的列: %s", column)
+		}
+	}
+
+	sources, err := s.querySources(ctx, table, query)
+	if err != nil {
+		return 0, err
+	}
+
+	// 分区之间时间不重叠，按时间顺序依次读取：先跳过 Offset 条，再取 Limit 条
+	results := reflect.MakeSlice(destValue.Elem().Type(), 0, max(query.Limit, 0))
+	offset := query.Offset
+	var total int64
+	for _, source := range sources {
+		if source.partition != nil && source.partition.Status == Models.TablePartitionArchived {
+			rows, err := s.archivedRows(ctx, table, sch, source.partition, query)
+			if err != nil {
+				return 0, err
+			}
+			count := rows.Len()
+			total += int64(count)
+			if wanted := query.Limit - results.Len(); (query.Limit <= 0 || wanted > 0) && offset < count {
+				end := count
+				if query.Limit > 0 {
+					end = min(count, offset+wanted)
+				}
+				results = reflect.AppendSlice(results, rows.Slice(offset, end))
+				offset = 0
+			} else {
+				offset = max(offset-count, 0)
+			}
+			continue
+		}
+
+		var count int64
+		if err := s.liveQuery(ctx, table, source.name, query).Count(&count).Error; err != nil {
+			return 0, err
+		}
+		total += count
+		if wanted := query.Limit - results.Len(); (query.Limit <= 0 || wanted > 0) && int64(offset) < count {
+			rows := reflect.New(destValue.Elem().Type())
+			q := s.liveQuery(ctx, table, source.name, query).Order(s.orderBy(table, query)).Offset(offset)
+			if query.Limit > 0 {
+				q = q.Limit(wanted)
+			}
+			if err := q.Find(rows.Interface()).Error; err != nil {
+				return 0, err
+			}
+			results = reflect.AppendSlice(results, rows.Elem())
+			offset = 0
+		} else {
+			offset = max(offset-int(count), 0)
+		}
+	}
+
+	destValue.Elem().Set(results)
+	return total, nil
+}
+
+// QueryRows 与 Query 相同，返回新建的模型切片，用于不关心具体类型的调用方
+func (s *TableArchiveService) QueryRows(ctx context.Context, tableName string, query ArchiveQuery) (interface{}, int64, error) {
+	table, ok := s.tables[tableName]
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: %s", ErrArchiveTableNotSupported, tableName)
+	}
+	rows := reflect.New(reflect.SliceOf(reflect.TypeOf(table.Model).Elem()))
+	total, err := s.Query(ctx, tableName, query, rows.Interface())
+	if err != nil {
+		return nil, 0, err
+	}
+	return rows.Elem().Interface(), total, nil
+}
+
+// querySources 返回与时间范围重叠的数据来源，按查询排序方向排列
+// 在线主表保存最新的数据，始终参与查询；PostgreSQL的在线分区通过主表查询
+func (s *TableArchiveService) querySources(ctx context.Context, table ArchivedTable, query ArchiveQuery) ([]archiveSource, error) {
+	partitions, err := s.Partitions(table.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := []archiveSource{{name: table.Name}}
+	for i := range partitions {
+		partition := &partitions[i]
+		if partition.Status == Models.TablePartitionLive && partition.Strategy == Models.TablePartitionNative {
+			continue
+		}
+		if !query.From.IsZero() && !partition.RangeEnd.After(query.From) {
+			continue
+		}
+		if !query.To.IsZero() && !partition.RangeStart.Before(query.To) {
+			continue
+		}
+		sources = append(sources, archiveSource{name: partition.PartitionName, partition: partition})
+	}
+
+	if query.Ascending {
+		for i, j := 0, len(sources)-1; i < j; i, j = i+1, j-1 {
+			sources[i], sources[j] = sources[j], sources[i]
+		}
+	}
+	return sources, nil
+}
+
+// liveQuery 构造在线表或月表的查询，按模型应用软删除条件
+func (s *TableArchiveService) liveQuery(ctx context.Context, table ArchivedTable, name string, query ArchiveQuery) *gorm.DB {
+	q := s.db.WithContext(ctx).Model(table.Model).Table(name)
+	timeColumn := s.quote(table.TimeColumn)
+	if !query.From.IsZero() {
+		q = q.Where(timeColumn+" >= ?", query.From)
+	}
+	if !query.To.IsZero() {
+		q = q.Where(timeColumn+" < ?", query.To)
+	}
+	if len(query.Where) > 0 {
+		q = q.Where(query.Where)
+	}
+	return q
+}
+
+// orderBy 按时间列和主键排序
+func (s *TableArchiveService) orderBy(table ArchivedTable, query ArchiveQuery) string {
+	direction := "DESC"
+	if query.Ascending {
+		direction = "ASC"
+	}
+	return fmt.Sprintf("%s %s, id %s", s.quote(table.TimeColumn), direction, direction)
+}
+
+// archivedRows 读取归档文件中符合条件的记录，已按查询方向排序
+func (s *TableArchiveService) archivedRows(ctx context.Context, table ArchivedTable, sch *schema.Schema, partition *Models.TablePartition, query ArchiveQuery) (reflect.Value, error) {
+	all, err := s.loadArchive(ctx, table, partition)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	timeField := sch.LookUpField(table.TimeColumn)
+	deletedField := sch.LookUpField("deleted_at")
+	if timeField == nil {
+		return reflect.Value{}, fmt.Errorf("模型缺少时间列: %s", table.TimeColumn)
+	}
+	filters := make(map[*schema.Field]interface{}, len(query.Where))
+	for column, value := range query.Where {
+		filters[sch.LookUpField(column)] = value
+	}
+
+	rows := reflect.MakeSlice(all.Type(), 0, 0)
+	times := make([]time.Time, 0)
+	for i := 0; i < all.Len(); i++ {
+		item := all.Index(i)
+		if deletedField != nil {
+			if _, zero := deletedField.ValueOf(ctx, item); !zero {
+				continue
+			}
+		}
+		value, _ := timeField.ValueOf(ctx, item)
+		at, _ := value.(time.Time)
+		if (!query.From.IsZero() && at.Before(query.From)) || (!query.To.IsZero() && !at.Before(query.To)) {
+			continue
+		}
+		if !archiveRowMatches(ctx, item, filters) {
+			continue
+		}
+		rows = reflect.Append(rows, item)
+		times = append(times, at)
+	}
+
+	// 归档文件按主键顺序写入，按时间重新排序
+	order := make([]int, rows.Len())
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		if query.Ascending {
+			return times[order[a]].Before(times[order[b]])
+		}
+		return times[order[a]].After(times[order[b]])
+	})
+	sorted := reflect.MakeSlice(all.Type(), 0, rows.Len())
+	for _, i := range order {
+		sorted = reflect.Append(sorted, rows.Index(i))
+	}
+	return sorted, nil
+}
+
+// archiveRowMatches 按列相等过滤归档记录，指针字段比较指向的值
+func archiveRowMatches(ctx context.Context, item reflect.Value, filters map[*schema.Field]interface{}) bool {
+	for field, want := range filters {
+		value, _ := field.ValueOf(ctx, item)
+		actual := reflect.ValueOf(value)
+		for actual.IsValid() && actual.Kind() == reflect.Ptr {
+			if actual.IsNil() {
+				actual = reflect.Value{}
+				break
+			}
+			actual = actual.Elem()
+		}
+		if !actual.IsValid() {
+			if want != nil {
+				return false
+			}
+			continue
+		}
+		if fmt.Sprint(actual.Interface()) != fmt.Sprint(want) {
+			return false
+		}
+	}
+	return true
+}
+
+// loadArchive 读取并解压归档文件，最近使用的 CacheSize 个文件缓存在内存中
+func (s *TableArchiveService) loadArchive(ctx context.Context, table ArchivedTable, partition *Models.TablePartition) (reflect.Value, error) {
+	sliceType := reflect.SliceOf(reflect.TypeOf(table.Model).Elem())
+	if partition.ArchiveKey == "" {
+		return reflect.MakeSlice(sliceType, 0, 0), nil
+	}
+
+	s.cacheMu.Lock()
+	for i, entry := range s.cache {
+		if entry.key == partition.ArchiveKey {
+			s.cache = append(append([]*archiveCacheEntry{entry}, s.cache[:i]...), s.cache[i+1:]...)
+			s.cacheMu.Unlock()
+			return entry.rows, nil
+		}
+	}
+	s.cacheMu.Unlock()
+
+	if s.store == nil {
+		return reflect.Value{}, errors.New("归档存储未配置")
+	}
+	data, err := s.store.Get(ctx, partition.ArchiveKey)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	defer reader.Close()
+
+	rows := reflect.MakeSlice(sliceType, 0, int(partition.RowCount))
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		item := reflect.New(sliceType.Elem())
+		if err := json.Unmarshal(scanner.Bytes(), item.Interface()); err != nil {
+			return reflect.Value{}, fmt.Errorf("解析归档文件 %s 失败: %w", partition.ArchiveKey, err)
+		}
+		rows = reflect.Append(rows, item.Elem())
+	}
+	if err := scanner.Err(); err != nil {
+		return reflect.Value{}, err
+	}
+
+	if size := s.config.CacheSize; size > 0 {
+		s.cacheMu.Lock()
+		s.cache = append([]*archiveCacheEntry{{key: partition.ArchiveKey, rows: rows}}, s.cache...)
+		if len(s.cache) > size {
+			s.cache = s.cache[:size]
+		}
+		s.cacheMu.Unlock()
+	}
+	return rows, nil
+}
@@ -0,0 +1,248 @@
+package Services
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 表格导出格式
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatXLSX = "xlsx"
+)
+
+// ErrUnsupportedExportFormat 不支持的表格导出格式
+var ErrUnsupportedExportFormat = errors.New("不支持的导出格式，支持 csv、xlsx")
+
+// exportFormatContentTypes 导出格式对应的Content-Type
+var exportFormatContentTypes = map[string]string{
+	ExportFormatCSV:  "text/csv; charset=utf-8",
+	ExportFormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// IsExportFormat 是否为支持的表格导出格式
+func IsExportFormat(format string) bool {
+	_, ok := exportFormatContentTypes[format]
+	return ok
+}
+
+// ExportContentType 导出格式对应的Content-Type
+func ExportContentType(format string) string {
+	return exportFormatContentTypes[format]
+}
+
+// TabularWriter 逐行写入表格，Close 时输出剩余内容
+// 单元格支持 string、整数、浮点数、bool、time.Time 和 nil
+type TabularWriter interface {
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+// NewTabularWriter 按格式创建表格写入器
+func NewTabularWriter(w io.Writer, format, sheetName string) (TabularWriter, error) {
+	switch format {
+	case ExportFormatCSV:
+		// 写入UTF-8 BOM，Excel直接打开时中文不乱码
+		if _, err := io.WriteString(w, "\uFEFF"); err != nil {
+			return nil, err
+		}
+		return &csvTabularWriter{writer: csv.NewWriter(w)}, nil
+	case ExportFormatXLSX:
+		return newXLSXTabularWriter(w, sheetName)
+	default:
+		return nil, ErrUnsupportedExportFormat
+	}
+}
+
+// csvTabularWriter CSV表格写入器
+type csvTabularWriter struct {
+	writer *csv.Writer
+	record []string
+}
+
+// WriteRow 写入一行，以 = + - @ 开头的文本加单引号前缀，防止表格软件当作公式执行
+func (w *csvTabularWriter) WriteRow(values []interface{}) error {
+	w.record = w.record[:0]
+	for _, value := range values {
+		text := formatExportCell(value)
+		if _, ok := value.(string); ok && text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+			text = "'" + text
+		}
+		w.record = append(w.record, text)
+	}
+	return w.writer.Write(w.record)
+}
+
+// Close 刷新缓冲区
+func (w *csvTabularWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// xlsxStaticParts 工作簿的固定部分，工作表内容在最后逐行写入
+var xlsxStaticParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`},
+	{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts><fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills><borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders><cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs><cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs></styleSheet>`},
+}
+
+// xlsxTabularWriter XLSX表格写入器
+// 工作表使用内联字符串逐行写入压缩流，不在内存中保存整个表格；第一行（表头）加粗并冻结
+type xlsxTabularWriter struct {
+	archive *zip.Writer
+	sheet   *bufio.Writer
+	rows    int
+}
+
+// newXLSXTabularWriter 写入工作簿固定部分并打开工作表
+func newXLSXTabularWriter(w io.Writer, sheetName string) (*xlsxTabularWriter, error) {
+	archive := zip.NewWriter(w)
+	for _, part := range xlsxStaticParts {
+		entry, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(entry, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	entry, err := archive.Create("xl/workbook.xml")
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(entry, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`, xmlEscape(xlsxSheetName(sheetName)))
+
+	entry, err = archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheet := bufio.NewWriter(entry)
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews><sheetData>`)
+	return &xlsxTabularWriter{archive: archive, sheet: sheet}, nil
+}
+
+// WriteRow 写入一行，数字和布尔值写为对应类型的单元格，其余写为文本
+func (w *xlsxTabularWriter) WriteRow(values []interface{}) error {
+	w.rows++
+	style := ""
+	if w.rows == 1 {
+		style = ` s="1"`
+	}
+	fmt.Fprintf(w.sheet, `<row r="%d">`, w.rows)
+	for i, value := range values {
+		ref := xlsxColumnName(i) + strconv.Itoa(w.rows)
+		switch v := value.(type) {
+		case nil:
+			continue
+		case int, int64, uint, uint64, float64:
+			fmt.Fprintf(w.sheet, `<c r="%s"%s><v>%s</v></c>`, ref, style, formatExportCell(v))
+		case *uint:
+			if v != nil {
+				fmt.Fprintf(w.sheet, `<c r="%s"%s><v>%d</v></c>`, ref, style, *v)
+			}
+		case bool:
+			flag := "0"
+			if v {
+				flag = "1"
+			}
+			fmt.Fprintf(w.sheet, `<c r="%s"%s t="b"><v>%s</v></c>`, ref, style, flag)
+		default:
+			fmt.Fprintf(w.sheet, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, xmlEscape(formatExportCell(v)))
+		}
+	}
+	_, err := w.sheet.WriteString("</row>")
+	return err
+}
+
+// Close 结束工作表并写入压缩包目录
+func (w *xlsxTabularWriter) Close() error {
+	if _, err := w.sheet.WriteString("</sheetData></worksheet>"); err != nil {
+		return err
+	}
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+	return w.archive.Close()
+}
+
+// formatExportCell 把单元格的值格式化为文本，时间使用RFC3339
+func formatExportCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case *time.Time:
+		if v == nil || v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case *uint:
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatUint(uint64(*v), 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// xlsxColumnName 列序号（从0开始）转换为列名，如 0 为 A、26 为 AA
+func xlsxColumnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// xlsxSheetName 工作表名称最多31个字符且不能包含 []:*?/\
+func xlsxSheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	if name == "" {
+		name = "Sheet1"
+	}
+	return name
+}
+
+// xmlEscape 转义XML文本，XML不允许的控制字符替换为U+FFFD
+func xmlEscape(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
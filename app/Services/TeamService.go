@@ -0,0 +1,575 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/mail"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrOrganizationNotFound 组织不存在或当前用户不可见
+	ErrOrganizationNotFound = errors.New("组织不存在")
+	// ErrOrganizationNameExists 组织名称已存在
+	ErrOrganizationNameExists = errors.New("组织名称已存在")
+	// ErrTeamNotFound 团队不存在或当前用户不可见
+	ErrTeamNotFound = errors.New("团队不存在")
+	// ErrTeamNameExists 组织内团队名称已存在
+	ErrTeamNameExists = errors.New("团队名称已存在")
+	// ErrTeamForbidden 当前用户无权管理组织或团队
+	ErrTeamForbidden = errors.New("无权管理该团队")
+	// ErrInvalidTeam 组织、团队或成员参数无效
+	ErrInvalidTeam = errors.New("团队参数无效")
+	// ErrTeamMemberNotFound 团队成员不存在
+	ErrTeamMemberNotFound = errors.New("团队成员不存在")
+	// ErrTeamMemberExists 用户已是团队成员
+	ErrTeamMemberExists = errors.New("用户已是团队成员")
+	// ErrLastTeamAdmin 移除或降级最后一名团队管理员
+	ErrLastTeamAdmin = errors.New("团队至少需要保留一名管理员")
+	// ErrInvitationNotFound 邀请不存在、已被接受或已撤销
+	ErrInvitationNotFound = errors.New("邀请不存在或已失效")
+	// ErrInvitationExpired 邀请已过期
+	ErrInvitationExpired = errors.New("邀请已过期")
+	// ErrInvitationEmailMismatch 邀请不是发给当前用户的邮箱
+	ErrInvitationEmailMismatch = errors.New("邀请不是发给当前用户的邮箱")
+)
+
+// TeamActor 执行组织和团队操作的用户
+type TeamActor struct {
+	UserID uint
+	Role   string
+}
+
+func (a TeamActor) isAdmin() bool {
+	return a.Role == "admin"
+}
+
+// TeamService 组织和团队服务
+// 功能说明：
+// 1. 用户创建组织后成为组织所有者，组织所有者在组织下创建团队，创建者成为团队管理员
+// 2. 团队管理员通过邮件邀请成员，被邀请的用户登录后凭邀请链接中的令牌加入团队，邀请只能使用一次
+// 3. 仪表板、告警规则和API密钥可以归属团队，权限检查通过 Memberships 获取用户所在团队
+// 4. 系统管理员和组织所有者可以管理组织下的所有团队
+type TeamService struct {
+	db     *gorm.DB
+	config *Config.TeamsConfig
+	mailer NotificationMailer
+	now    func() time.Time
+}
+
+// NewTeamService 创建组织和团队服务
+//
+// config 为 nil 时使用全局配置，全局配置未加载时使用默认值；mailer 为 nil 且全局邮件服务已配置时使用 EmailService。
+func NewTeamService(db *gorm.DB, config *Config.TeamsConfig, mailer NotificationMailer) *TeamService {
+	if config == nil {
+		if config = Config.GetTeamsConfig(); config == nil {
+			config = &Config.TeamsConfig{
+				InvitationTTL:     7 * 24 * time.Hour,
+				InvitationBaseURL: "http://localhost:3000/invitations/accept",
+			}
+		}
+	}
+	if mailer == nil {
+		mailer = defaultNotificationMailer()
+	}
+	return &TeamService{db: db, config: config, mailer: mailer, now: time.Now}
+}
+
+// SetClock 设置当前时间函数，用于测试邀请过期
+func (s *TeamService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// CreateOrganization 创建组织，当前用户为所有者
+func (s *TeamService) CreateOrganization(name, description string, actor TeamActor) (*Models.Organization, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return nil, fmt.Errorf("%w：组织名称不能为空且不超过100个字符", ErrInvalidTeam)
+	}
+
+	organization := &Models.Organization{Name: name, Description: description, OwnerID: actor.UserID}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&Models.Organization{}).Where("name = ?", name).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrOrganizationNameExists
+		}
+		return tx.Create(organization).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return organization, nil
+}
+
+// ListOrganizations 获取当前用户拥有或所在团队所属的组织，管理员返回全部组织
+func (s *TeamService) ListOrganizations(actor TeamActor) ([]Models.Organization, error) {
+	query := s.db.Order("name ASC")
+	if !actor.isAdmin() {
+		query = query.Where("owner_id = ? OR id IN (?)", actor.UserID,
+			s.db.Model(&Models.Team{}).Select("organization_id").Where("id IN (?)", s.memberTeamIDs(actor.UserID)))
+	}
+	var organizations []Models.Organization
+	if err := query.Find(&organizations).Error; err != nil {
+		return nil, err
+	}
+	return organizations, nil
+}
+
+// CreateTeam 在组织下创建团队，只有组织所有者和管理员可以创建，创建者成为团队管理员
+func (s *TeamService) CreateTeam(organizationID uint, name, description string, actor TeamActor) (*Models.Team, error) {
+	var organization Models.Organization
+	if err := s.db.First(&organization, organizationID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrganizationNotFound
+		}
+		return nil, err
+	}
+	if !actor.isAdmin() && organization.OwnerID != actor.UserID {
+		return nil, ErrTeamForbidden
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return nil, fmt.Errorf("%w：团队名称不能为空且不超过100个字符", ErrInvalidTeam)
+	}
+
+	team := &Models.Team{OrganizationID: organization.ID, Name: name, Description: description, CreatedBy: actor.UserID}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&Models.Team{}).Where("organization_id = ? AND name = ?", organization.ID, name).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrTeamNameExists
+		}
+		if err := tx.Create(team).Error; err != nil {
+			return err
+		}
+		return tx.Create(&Models.TeamMember{TeamID: team.ID, UserID: actor.UserID, Role: Models.TeamRoleAdmin}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// ListTeams 获取当前用户所在的团队和所拥有组织下的团队，管理员返回全部团队
+func (s *TeamService) ListTeams(actor TeamActor) ([]Models.Team, error) {
+	query := s.db.Order("organization_id ASC, name ASC")
+	if !actor.isAdmin() {
+		query = query.Where("id IN (?) OR organization_id IN (?)", s.memberTeamIDs(actor.UserID),
+			s.db.Model(&Models.Organization{}).Select("id").Where("owner_id = ?", actor.UserID))
+	}
+	var teams []Models.Team
+	if err := query.Find(&teams).Error; err != nil {
+		return nil, err
+	}
+	return teams, nil
+}
+
+// GetTeam 获取团队及其成员，只有团队成员、组织所有者和管理员可见
+func (s *TeamService) GetTeam(teamID uint, actor TeamActor) (*Models.Team, error) {
+	team, role, err := s.team(teamID, actor)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, ErrTeamNotFound
+	}
+	err = s.db.Preload("User", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username", "email") }).
+		Where("team_id = ?", team.ID).Order("id ASC").Find(&team.Members).Error
+	if err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// UpdateMemberRole 修改成员角色，只有团队管理员、组织所有者和管理员可以修改
+func (s *TeamService) UpdateMemberRole(teamID, userID uint, role string, actor TeamActor) (*Models.TeamMember, error) {
+	if role != Models.TeamRoleAdmin && role != Models.TeamRoleMember {
+		return nil, fmt.Errorf("%w：角色只能是 admin 或 member", ErrInvalidTeam)
+	}
+	if _, err := s.manageableTeam(teamID, actor); err != nil {
+		return nil, err
+	}
+
+	var member Models.TeamMember
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("team_id = ? AND user_id = ?", teamID, userID).First(&member).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTeamMemberNotFound
+			}
+			return err
+		}
+		if member.Role == Models.TeamRoleAdmin && role != Models.TeamRoleAdmin {
+			if err := ensureOtherTeamAdmin(tx, teamID, userID); err != nil {
+				return err
+			}
+		}
+		member.Role = role
+		return tx.Save(&member).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// RemoveMember 移除成员，团队管理员、组织所有者和管理员可以移除任何成员，成员可以退出团队
+func (s *TeamService) RemoveMember(teamID, userID uint, actor TeamActor) error {
+	if userID == actor.UserID {
+		if _, role, err := s.team(teamID, actor); err != nil {
+			return err
+		} else if role == "" {
+			return ErrTeamNotFound
+		}
+	} else if _, err := s.manageableTeam(teamID, actor); err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var member Models.TeamMember
+		if err := tx.Where("team_id = ? AND user_id = ?", teamID, userID).First(&member).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTeamMemberNotFound
+			}
+			return err
+		}
+		if member.Role == Models.TeamRoleAdmin {
+			if err := ensureOtherTeamAdmin(tx, teamID, userID); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&member).Error
+	})
+}
+
+// Invite 邀请邮箱加入团队并发送邀请邮件，返回邀请和明文令牌
+//
+// 同一邮箱未接受的旧邀请被新邀请替换；邮件服务未配置时只创建邀请，由调用方把令牌转交给被邀请人。
+func (s *TeamService) Invite(teamID uint, email, role string, actor TeamActor) (*Models.TeamInvitation, string, error) {
+	team, err := s.manageableTeam(teamID, actor)
+	if err != nil {
+		return nil, "", err
+	}
+	address, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil || address.Name != "" {
+		return nil, "", fmt.Errorf("%w：邮箱格式无效", ErrInvalidTeam)
+	}
+	email = strings.ToLower(address.Address)
+	if role == "" {
+		role = Models.TeamRoleMember
+	}
+	if role != Models.TeamRoleAdmin && role != Models.TeamRoleMember {
+		return nil, "", fmt.Errorf("%w：角色只能是 admin 或 member", ErrInvalidTeam)
+	}
+
+	var members int64
+	err = s.db.Model(&Models.TeamMember{}).
+		Where("team_id = ? AND user_id IN (?)", team.ID, s.db.Model(&Models.User{}).Select("id").Where("LOWER(email) = ?", email)).
+		Count(&members).Error
+	if err != nil {
+		return nil, "", err
+	}
+	if members > 0 {
+		return nil, "", ErrTeamMemberExists
+	}
+
+	token, err := randomTeamToken()
+	if err != nil {
+		return nil, "", err
+	}
+	invitation := &Models.TeamInvitation{
+		TeamID:    team.ID,
+		Email:     email,
+		Role:      role,
+		TokenHash: hashTeamToken(token),
+		InvitedBy: actor.UserID,
+		ExpiresAt: s.now().Add(s.config.InvitationTTL),
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("team_id = ? AND email = ? AND accepted_at IS NULL", team.ID, email).
+			Delete(&Models.TeamInvitation{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(invitation).Error
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.sendInvitationEmail(team, invitation, token, actor); err != nil {
+		log.Printf("发送团队邀请邮件失败: team=%d, email=%s, error=%v", team.ID, email, err)
+	}
+	return invitation, token, nil
+}
+
+// ListInvitations 获取团队未接受且未过期的邀请
+func (s *TeamService) ListInvitations(teamID uint, actor TeamActor) ([]Models.TeamInvitation, error) {
+	if _, err := s.manageableTeam(teamID, actor); err != nil {
+		return nil, err
+	}
+	var invitations []Models.TeamInvitation
+	err := s.db.Where("team_id = ? AND accepted_at IS NULL AND expires_at > ?", teamID, s.now()).
+		Order("id DESC").Find(&invitations).Error
+	if err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// RevokeInvitation 撤销未接受的邀请
+func (s *TeamService) RevokeInvitation(teamID, invitationID uint, actor TeamActor) error {
+	if _, err := s.manageableTeam(teamID, actor); err != nil {
+		return err
+	}
+	result := s.db.Where("id = ? AND team_id = ? AND accepted_at IS NULL", invitationID, teamID).Delete(&Models.TeamInvitation{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
+
+// AcceptInvitation 当前用户接受邀请加入团队，用户邮箱必须与邀请邮箱一致
+func (s *TeamService) AcceptInvitation(token string, userID uint) (*Models.TeamMember, error) {
+	var user Models.User
+	if err := s.db.Select("id", "email").First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	var member Models.TeamMember
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var invitation Models.TeamInvitation
+		if err := tx.Where("token_hash = ? AND accepted_at IS NULL", hashTeamToken(token)).First(&invitation).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvitationNotFound
+			}
+			return err
+		}
+		now := s.now()
+		if !invitation.Pending(now) {
+			return ErrInvitationExpired
+		}
+		if !strings.EqualFold(strings.TrimSpace(user.Email), invitation.Email) {
+			return ErrInvitationEmailMismatch
+		}
+
+		// 只有仍未接受的邀请才会被更新，并发接受同一邀请时只有一个成功
+		result := tx.Model(&Models.TeamInvitation{}).Where("id = ? AND accepted_at IS NULL", invitation.ID).
+			Updates(map[string]interface{}{"accepted_at": now, "accepted_by": user.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvitationNotFound
+		}
+
+		err := tx.Where("team_id = ? AND user_id = ?", invitation.TeamID, user.ID).First(&member).Error
+		switch {
+		case err == nil:
+			return ErrTeamMemberExists
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+		member = Models.TeamMember{TeamID: invitation.TeamID, UserID: user.ID, Role: invitation.Role}
+		return tx.Create(&member).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// Memberships 获取用户所在的团队及其角色
+func (s *TeamService) Memberships(userID uint) map[uint]string {
+	return teamMemberships(s.db, userID)
+}
+
+// CanAssign 用户是否可以把资源归属到团队：管理员和团队成员可以
+func (s *TeamService) CanAssign(teamID uint, actor TeamActor) bool {
+	if actor.isAdmin() {
+		return true
+	}
+	_, ok := s.Memberships(actor.UserID)[teamID]
+	return ok
+}
+
+// CanManageResource 用户是否可以修改和删除资源：管理员、资源创建者和所属团队的成员可以
+func (s *TeamService) CanManageResource(teamID *uint, createdBy uint, actor TeamActor) bool {
+	if actor.isAdmin() || (actor.UserID != 0 && createdBy == actor.UserID) {
+		return true
+	}
+	return teamID != nil && s.CanAssign(*teamID, actor)
+}
+
+// team 获取团队和当前用户的访问级别：管理员、组织所有者和团队管理员返回 admin，团队成员返回 member，其他用户返回空
+func (s *TeamService) team(teamID uint, actor TeamActor) (*Models.Team, string, error) {
+	var team Models.Team
+	if err := s.db.First(&team, teamID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", ErrTeamNotFound
+		}
+		return nil, "", err
+	}
+	if actor.isAdmin() {
+		return &team, Models.TeamRoleAdmin, nil
+	}
+	var organization Models.Organization
+	if err := s.db.Select("id", "owner_id").First(&organization, team.OrganizationID).Error; err == nil && organization.OwnerID == actor.UserID {
+		return &team, Models.TeamRoleAdmin, nil
+	}
+	var member Models.TeamMember
+	err := s.db.Where("team_id = ? AND user_id = ?", team.ID, actor.UserID).First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &team, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return &team, member.Role, nil
+}
+
+// manageableTeam 获取当前用户可以管理成员的团队
+func (s *TeamService) manageableTeam(teamID uint, actor TeamActor) (*Models.Team, error) {
+	team, role, err := s.team(teamID, actor)
+	if err != nil {
+		return nil, err
+	}
+	switch role {
+	case Models.TeamRoleAdmin:
+		return team, nil
+	case "":
+		return nil, ErrTeamNotFound
+	default:
+		return nil, ErrTeamForbidden
+	}
+}
+
+// memberTeamIDs 用户所在团队ID的子查询
+func (s *TeamService) memberTeamIDs(userID uint) *gorm.DB {
+	return s.db.Model(&Models.TeamMember{}).Select("team_id").Where("user_id = ?", userID)
+}
+
+// sendInvitationEmail 发送团队邀请邮件，邀请邮件直接发送，不受用户通知偏好影响
+func (s *TeamService) sendInvitationEmail(team *Models.Team, invitation *Models.TeamInvitation, token string, actor TeamActor) error {
+	if s.mailer == nil || s.config.InvitationBaseURL == "" {
+		return nil
+	}
+	inviter := "团队管理员"
+	var user Models.User
+	if err := s.db.Select("id", "username").First(&user, actor.UserID).Error; err == nil && user.Username != "" {
+		inviter = user.Username
+	}
+
+	separator := "?"
+	if strings.Contains(s.config.InvitationBaseURL, "?") {
+		separator = "&"
+	}
+	acceptURL := s.config.InvitationBaseURL + separator + "token=" + url.QueryEscape(token)
+	body := fmt.Sprintf(`
+		<h2>团队邀请</h2>
+		<p>%s 邀请您加入团队「%s」。</p>
+		<p>请使用该邮箱对应的账户登录后点击以下链接接受邀请：</p>
+		<p><a href="%s">接受邀请</a></p>
+		<p>此链接将于 %s 过期。如果您不认识邀请人，请忽略此邮件。</p>
+	`, html.EscapeString(inviter), html.EscapeString(team.Name), html.EscapeString(acceptURL),
+		invitation.ExpiresAt.Format("2006-01-02 15:04:05"))
+
+	return s.mailer.SendNotificationEmail(invitation.Email, "团队邀请", body)
+}
+
+// ensureOtherTeamAdmin 确认团队中除该用户外还有其他管理员
+func ensureOtherTeamAdmin(tx *gorm.DB, teamID, userID uint) error {
+	var admins int64
+	err := tx.Model(&Models.TeamMember{}).
+		Where("team_id = ? AND role = ? AND user_id <> ?", teamID, Models.TeamRoleAdmin, userID).Count(&admins).Error
+	if err != nil {
+		return err
+	}
+	if admins == 0 {
+		return ErrLastTeamAdmin
+	}
+	return nil
+}
+
+// teamMemberships 查询用户所在的团队及其角色，查询失败（如未创建团队表）时返回 nil，视为不属于任何团队
+func teamMemberships(db *gorm.DB, userID uint) map[uint]string {
+	if db == nil || userID == 0 {
+		return nil
+	}
+	var members []Models.TeamMember
+	if err := db.Select("team_id", "role").Where("user_id = ?", userID).Find(&members).Error; err != nil {
+		return nil
+	}
+	memberships := make(map[uint]string, len(members))
+	for _, member := range members {
+		memberships[member.TeamID] = member.Role
+	}
+	return memberships
+}
+
+// teamIDList 返回所在团队的ID
+func teamIDList(memberships map[uint]string) []uint {
+	ids := make([]uint, 0, len(memberships))
+	for id := range memberships {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// randomTeamToken 生成邀请令牌
+func randomTeamToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// hashTeamToken 计算邀请令牌哈希
+func hashTeamToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+var (
+	defaultTeamService   *TeamService
+	defaultTeamServiceMu sync.RWMutex
+)
+
+// SetDefaultTeamService 设置全局组织和团队服务
+func SetDefaultTeamService(service *TeamService) {
+	defaultTeamServiceMu.Lock()
+	defer defaultTeamServiceMu.Unlock()
+	defaultTeamService = service
+}
+
+// DefaultTeamService 获取全局组织和团队服务，未设置时使用 db 创建，db 为 nil 时返回 nil
+func DefaultTeamService(db *gorm.DB) *TeamService {
+	defaultTeamServiceMu.RLock()
+	defer defaultTeamServiceMu.RUnlock()
+	if defaultTeamService != nil {
+		return defaultTeamService
+	}
+	if db == nil {
+		return nil
+	}
+	return NewTeamService(db, nil, nil)
+}
@@ -0,0 +1,227 @@
+package Services
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// telegramDependency Telegram在出站HTTP客户端中的依赖名称
+const telegramDependency = "telegram"
+
+// telegramMaxMessageRunes Telegram单条消息上限为4096个字符，预留格式和提及的长度
+const telegramMaxMessageRunes = 3500
+
+// TelegramNotificationChannel Telegram机器人通知通道
+// 功能说明：
+// 1. 通过Bot API的 sendMessage 将告警发送到 NotificationConfig.Telegram 配置的每个会话
+// 2. ParseMode 为 MarkdownV2 时标题加粗并转义特殊字符，MentionUsers 中的用户名附加在消息末尾
+// 3. 按会话限流，群组每分钟最多20条；服务商返回429时按 retry_after 暂停该会话
+type TelegramNotificationChannel struct {
+	apiURL    string
+	botToken  string
+	chatIDs   []string
+	parseMode string
+	mentions  []string
+	enabled   bool
+	client    *OutboundHTTPClient
+	limiter   *notificationRateLimiter
+}
+
+// NewTelegramNotificationChannel 创建Telegram通知通道
+//
+// client 为 nil 时使用全局出站HTTP客户端。
+func NewTelegramNotificationChannel(config *Config.MonitoringConfig, client *OutboundHTTPClient) *TelegramNotificationChannel {
+	if client == nil {
+		client = GetOutboundHTTPClient()
+	}
+
+	// 限流由通道自己处理，出站客户端不再对429重试
+	policy := client.DefaultPolicy()
+	policy.MaxRetries = 0
+	client.SetPolicy(telegramDependency, policy)
+
+	telegram := config.NotificationConfig.Telegram
+	apiURL := strings.TrimRight(telegram.APIURL, "/")
+	if apiURL == "" {
+		apiURL = "https://api.telegram.org"
+	}
+
+	var mentions []string
+	for _, user := range splitNotificationList(telegram.MentionUsers) {
+		if !strings.HasPrefix(user, "@") {
+			user = "@" + user
+		}
+		mentions = append(mentions, user)
+	}
+
+	return &TelegramNotificationChannel{
+		apiURL:    apiURL,
+		botToken:  telegram.BotToken,
+		chatIDs:   splitNotificationList(telegram.ChatIDs),
+		parseMode: telegram.ParseMode,
+		mentions:  mentions,
+		enabled:   telegram.Enabled,
+		client:    client,
+		limiter:   newNotificationRateLimiter(telegram.RateLimit, time.Minute),
+	}
+}
+
+// splitNotificationList 解析逗号分隔的配置项，忽略空白项
+func splitNotificationList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// telegramMarkdownV2Replacer 转义MarkdownV2中需要转义的字符
+var telegramMarkdownV2Replacer = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+	"~", `\~`, "`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`,
+	"|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+// EscapeTelegramMarkdownV2 转义文本，使其在MarkdownV2消息中按原样显示
+func EscapeTelegramMarkdownV2(text string) string {
+	return telegramMarkdownV2Replacer.Replace(text)
+}
+
+// FormatTelegramMessage 将告警格式化为Telegram消息文本
+func (tnc *TelegramNotificationChannel) FormatTelegramMessage(alert MonitoringAlert) string {
+	message := alert.Message
+	if runes := []rune(message); len(runes) > telegramMaxMessageRunes {
+		message = string(runes[:telegramMaxMessageRunes]) + "..."
+	}
+
+	escape := func(text string) string { return text }
+	bold := func(text string) string { return text }
+	if tnc.parseMode == "MarkdownV2" {
+		escape = EscapeTelegramMarkdownV2
+		bold = func(text string) string { return "*" + text + "*" }
+	}
+
+	var b strings.Builder
+	b.WriteString(bold(escape(fmt.Sprintf("[%s] %s", strings.ToUpper(alert.Severity), alert.Title))))
+	b.WriteString("\n")
+	b.WriteString(escape(message))
+	if alert.Source != "" {
+		b.WriteString("\n")
+		b.WriteString(escape("来源: " + alert.Source))
+	}
+	if !alert.Timestamp.IsZero() {
+		b.WriteString("\n")
+		b.WriteString(escape("时间: " + alert.Timestamp.Format("2006-01-02 15:04:05")))
+	}
+	if len(tnc.mentions) > 0 {
+		b.WriteString("\n")
+		b.WriteString(escape(strings.Join(tnc.mentions, " ")))
+	}
+	return b.String()
+}
+
+// telegramResponse Bot API响应
+type telegramResponse struct {
+	OK          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+	Parameters  struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+// SendAlert 发送告警，任一会话发送失败时返回汇总错误
+func (tnc *TelegramNotificationChannel) SendAlert(alert MonitoringAlert) error {
+	if !tnc.enabled {
+		return fmt.Errorf("Telegram通知通道已禁用")
+	}
+	if tnc.botToken == "" || len(tnc.chatIDs) == 0 {
+		return fmt.Errorf("Telegram通知通道未配置机器人令牌或会话ID")
+	}
+
+	text := tnc.FormatTelegramMessage(alert)
+	var errs []error
+	for _, chatID := range tnc.chatIDs {
+		if err := tnc.sendMessage(chatID, text); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", chatID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sendMessage 向单个会话发送消息
+func (tnc *TelegramNotificationChannel) sendMessage(chatID, text string) error {
+	if ok, wait := tnc.limiter.Allow(chatID); !ok {
+		return &NotificationRateLimitError{Channel: "Telegram", Target: chatID, RetryAfter: wait}
+	}
+
+	payload := map[string]interface{}{
+		"chat_id":                  chatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	}
+	if tnc.parseMode != "" {
+		payload["parse_mode"] = tnc.parseMode
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化Telegram消息失败: %v", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", tnc.apiURL, tnc.botToken)
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建Telegram请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := tnc.client.Do(telegramDependency, req)
+	if err != nil {
+		// 请求地址中包含机器人令牌，不能原样返回
+		return fmt.Errorf("发送Telegram告警失败: %s", strings.ReplaceAll(err.Error(), tnc.botToken, "***"))
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	var result telegramResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return fmt.Errorf("Telegram返回错误状态: %s", resp.Status)
+	}
+	if result.OK {
+		return nil
+	}
+	if result.ErrorCode == http.StatusTooManyRequests {
+		retryAfter := time.Duration(result.Parameters.RetryAfter) * time.Second
+		if retryAfter <= 0 {
+			retryAfter = time.Minute
+		}
+		tnc.limiter.Block(chatID, retryAfter)
+		return &NotificationRateLimitError{Channel: "Telegram", Target: chatID, RetryAfter: retryAfter}
+	}
+	return fmt.Errorf("Telegram返回错误: %d %s", result.ErrorCode, result.Description)
+}
+
+// GetName 获取通道名称
+func (tnc *TelegramNotificationChannel) GetName() string {
+	return telegramDependency
+}
+
+// IsEnabled 检查是否启用
+func (tnc *TelegramNotificationChannel) IsEnabled() bool {
+	return tnc.enabled && tnc.botToken != "" && len(tnc.chatIDs) > 0
+}
+
+// SetEnabled 设置启用状态
+func (tnc *TelegramNotificationChannel) SetEnabled(enabled bool) {
+	tnc.enabled = enabled
+}
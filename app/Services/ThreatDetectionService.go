@@ -7,7 +7,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 	"regexp"
 	"strings"
 	"sync"
@@ -106,7 +105,7 @@ func (s *ThreatDetectionService) initialize() {
 func (s *ThreatDetectionService) loadLocalThreatIntelligence() {
 	// 从数据库加载威胁情报
 	var threats []Models.ThreatIntelligence
-	s.db.Where("active = ?", true).Find(&threats)
+	s.db.WithContext(s.ctx).Where("active = ?", true).Find(&threats)
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -179,21 +178,15 @@ func (s *ThreatDetectionService) getThreatIntelligenceSources() []ThreatIntellig
 }
 
 // fetchThreatIntelligence 获取威胁情报
+// 通过出站HTTP客户端请求，每个情报源作为独立依赖统计和熔断
 func (s *ThreatDetectionService) fetchThreatIntelligence(source ThreatIntelligenceSource) {
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
-
-	req, err := http.NewRequestWithContext(s.ctx, "GET", source.URL, nil)
-	if err != nil {
-		return
-	}
-
+	headers := map[string]string{}
 	if source.APIKey != "" {
-		req.Header.Set("Authorization", "Bearer "+source.APIKey)
+		headers["Authorization"] = "Bearer " + source.APIKey
 	}
 
-	resp, err := client.Do(req)
+	dependency := "threat_intel:" + source.Name
+	resp, err := threatIntelClient(dependency).Get(s.ctx, dependency, source.URL, headers)
 	if err != nil {
 		return
 	}
@@ -208,6 +201,18 @@ func (s *ThreatDetectionService) fetchThreatIntelligence(source ThreatIntelligen
 	s.parseThreatIntelligence(source.Name, body)
 }
 
+// threatIntelTimeout 威胁情报源的请求超时，情报列表较大，需要比默认超时更长
+const threatIntelTimeout = 30 * time.Second
+
+// threatIntelClient 返回全局出站HTTP客户端，并为情报源设置更长的超时
+func threatIntelClient(dependency string) *OutboundHTTPClient {
+	client := GetOutboundHTTPClient()
+	policy := client.DefaultPolicy()
+	policy.Timeout = threatIntelTimeout
+	client.SetPolicy(dependency, policy)
+	return client
+}
+
 // parseThreatIntelligence 解析威胁情报数据
 func (s *ThreatDetectionService) parseThreatIntelligence(source string, data []byte) {
 	lines := strings.Split(string(data), "\n")
@@ -321,7 +326,7 @@ func (s *ThreatDetectionService) performThreatDetection() {
 func (s *ThreatDetectionService) detectSuspiciousLogins() {
 	// 查找最近1小时内的失败登录
 	var failedLogins []Models.LoginAttempt
-	s.db.Where("success = ? AND attempt_time > ?", false, time.Now().Add(-time.Hour)).
+	s.db.WithContext(s.ctx).Where("success = ? AND attempt_time > ?", false, time.Now().Add(-time.Hour)).
 		Find(&failedLogins)
 
 	// 按IP地址分组统计
@@ -342,7 +347,7 @@ func (s *ThreatDetectionService) detectSuspiciousLogins() {
 func (s *ThreatDetectionService) detectAnomalousNetworkActivity() {
 	// 查找最近1小时内的安全事件
 	var events []Models.SecurityEvent
-	s.db.Where("created_at > ?", time.Now().Add(-time.Hour)).
+	s.db.WithContext(s.ctx).Where("created_at > ?", time.Now().Add(-time.Hour)).
 		Find(&events)
 
 	// 按IP地址分组统计
@@ -367,7 +372,7 @@ func (s *ThreatDetectionService) detectMaliciousFileUploads() {
 
 	// 查找最近1小时内的文件上传事件
 	var events []Models.SecurityEvent
-	s.db.Where("event_type = ? AND created_at > ?", "file_upload", time.Now().Add(-time.Hour)).
+	s.db.WithContext(s.ctx).Where("event_type = ? AND created_at > ?", "file_upload", time.Now().Add(-time.Hour)).
 		Find(&events)
 
 	for _, event := range events {
@@ -394,7 +399,12 @@ func (s *ThreatDetectionService) recordThreatEvent(eventType, severity, ipAddres
 		Alerted:      true,
 	}
 
-	s.db.Create(&event)
+	s.db.WithContext(s.ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&event).Error; err != nil {
+			return err
+		}
+		return publishSecurityEvent(tx, &event)
+	})
 }
 
 // calculateRiskScore 计算风险分数
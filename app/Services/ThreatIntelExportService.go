@@ -0,0 +1,487 @@
+package Services
+
+import (
+	"bufio"
+	"cloud-platform-api/app/Models"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 威胁情报导出格式
+const (
+	ThreatIntelFormatJSON = "json"
+	ThreatIntelFormatCSV  = "csv"
+	ThreatIntelFormatSTIX = "stix"
+)
+
+// 威胁情报指标类型
+const (
+	IndicatorTypeIP     = "ip"
+	IndicatorTypeDomain = "domain"
+	IndicatorTypeURL    = "url"
+	IndicatorTypeHash   = "hash"
+)
+
+// ErrUnsupportedThreatIntelFormat 不支持的导出格式
+var ErrUnsupportedThreatIntelFormat = errors.New("不支持的威胁情报导出格式")
+
+// ErrUnsupportedIndicatorType 不支持的指标类型
+var ErrUnsupportedIndicatorType = errors.New("不支持的威胁情报指标类型")
+
+// threatIntelExportBatchSize 每批从数据库读取的情报记录数
+const threatIntelExportBatchSize = 500
+
+// stixNamespace 生成STIX对象ID的命名空间，相同指标多次导出的ID保持不变，便于下游去重
+var stixNamespace = uuid.NewSHA1(uuid.NameSpaceDNS, []byte("threat-intel.cloud-platform-api"))
+
+// ThreatIntelExportFilter 威胁情报导出条件
+type ThreatIntelExportFilter struct {
+	IndicatorType string    // 指标类型（ip、domain、url、hash），为空时导出全部指标
+	ThreatType    string    // 威胁类型
+	Severities    []string  // 严重程度
+	Since         time.Time // 只导出该时间之后更新的情报
+	AfterID       uint      // 分页游标，只导出ID大于该值的情报记录
+	Limit         int       // 最多导出的情报记录数，0表示全部
+}
+
+// ThreatIntelExportResult 威胁情报导出结果
+type ThreatIntelExportResult struct {
+	Records    int  `json:"records"`     // 导出的情报记录数
+	Indicators int  `json:"indicators"`  // 导出的指标数（一条记录可能包含IP、域名等多个指标）
+	LastID     uint `json:"last_id"`     // 最后一条记录的ID
+	NextCursor uint `json:"next_cursor"` // 下一页游标，0表示没有更多数据
+}
+
+// ThreatIndicator 威胁情报指标
+type ThreatIndicator struct {
+	ID          uint      `json:"id"`
+	Type        string    `json:"type"`
+	Value       string    `json:"value"`
+	ThreatType  string    `json:"threat_type"`
+	Severity    string    `json:"severity"`
+	Confidence  int       `json:"confidence"`
+	Source      string    `json:"source"`
+	Description string    `json:"description"`
+	Tags        []string  `json:"tags"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ThreatIntelExporter 威胁情报导出器
+// 功能说明：
+// 1. 从 threat_intelligences 表按类型、严重程度、更新时间筛选活跃情报
+// 2. 按ID游标分批读取并直接写入输出流，导出大量数据时内存占用固定
+// 3. 支持 JSON、CSV 和 STIX 2.1 Bundle 三种格式
+type ThreatIntelExporter struct {
+	db        *gorm.DB
+	batchSize int
+}
+
+// NewThreatIntelExporter 创建威胁情报导出器
+func NewThreatIntelExporter(db *gorm.DB) *ThreatIntelExporter {
+	return &ThreatIntelExporter{db: db, batchSize: threatIntelExportBatchSize}
+}
+
+// IsThreatIntelFormat 检查是否为支持的导出格式
+func IsThreatIntelFormat(format string) bool {
+	switch format {
+	case ThreatIntelFormatJSON, ThreatIntelFormatCSV, ThreatIntelFormatSTIX:
+		return true
+	}
+	return false
+}
+
+// IsIndicatorType 检查是否为支持的指标类型
+func IsIndicatorType(indicatorType string) bool {
+	_, err := indicatorColumn(indicatorType)
+	return err == nil && indicatorType != ""
+}
+
+// ThreatIntelContentType 获取导出格式对应的Content-Type
+func ThreatIntelContentType(format string) string {
+	switch format {
+	case ThreatIntelFormatCSV:
+		return "text/csv; charset=utf-8"
+	case ThreatIntelFormatSTIX:
+		return "application/stix+json;version=2.1"
+	default:
+		return "application/json"
+	}
+}
+
+// ThreatIntelFileExtension 获取导出格式对应的文件扩展名
+func ThreatIntelFileExtension(format string) string {
+	switch format {
+	case ThreatIntelFormatCSV:
+		return ".csv"
+	case ThreatIntelFormatSTIX:
+		return ".stix.json"
+	default:
+		return ".json"
+	}
+}
+
+// Export 按条件导出威胁情报
+//
+// 数据分批写入 w，w 实现 Flush 时每批写完后刷新；写入过程中出错时输出可能不完整。
+func (e *ThreatIntelExporter) Export(ctx context.Context, w io.Writer, format string, filter ThreatIntelExportFilter) (*ThreatIntelExportResult, error) {
+	if !IsThreatIntelFormat(format) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedThreatIntelFormat, format)
+	}
+	column, err := indicatorColumn(filter.IndicatorType)
+	if err != nil {
+		return nil, err
+	}
+
+	buffered := bufio.NewWriter(w)
+	encoder := newIndicatorEncoder(format, buffered)
+	if err := encoder.begin(); err != nil {
+		return nil, err
+	}
+
+	result := &ThreatIntelExportResult{}
+	cursor := filter.AfterID
+	for {
+		size := e.batchSize
+		if filter.Limit > 0 && filter.Limit-result.Records < size {
+			size = filter.Limit - result.Records
+		}
+
+		var rows []Models.ThreatIntelligence
+		if err := e.query(ctx, filter, column).Where("id > ?", cursor).Order("id ASC").Limit(size).Find(&rows).Error; err != nil {
+			return result, fmt.Errorf("查询威胁情报失败: %v", err)
+		}
+
+		for _, row := range rows {
+			for _, indicator := range buildThreatIndicators(row, filter.IndicatorType) {
+				if err := encoder.write(indicator); err != nil {
+					return result, err
+				}
+				result.Indicators++
+			}
+			cursor = row.ID
+		}
+		result.Records += len(rows)
+		result.LastID = cursor
+
+		if len(rows) < size {
+			break
+		}
+		if filter.Limit > 0 && result.Records >= filter.Limit {
+			var more int64
+			if err := e.query(ctx, filter, column).Where("id > ?", cursor).Limit(1).Count(&more).Error; err == nil && more > 0 {
+				result.NextCursor = cursor
+			}
+			break
+		}
+
+		if err := buffered.Flush(); err != nil {
+			return result, err
+		}
+		if flusher, ok := w.(interface{ Flush() }); ok {
+			flusher.Flush()
+		}
+	}
+
+	if err := encoder.end(result); err != nil {
+		return result, err
+	}
+	return result, buffered.Flush()
+}
+
+// query 构建筛选条件
+func (e *ThreatIntelExporter) query(ctx context.Context, filter ThreatIntelExportFilter, column string) *gorm.DB {
+	query := e.db.WithContext(ctx).Model(&Models.ThreatIntelligence{}).Where("active = ?", true)
+	if column != "" {
+		query = query.Where(column + " <> ''")
+	}
+	if filter.ThreatType != "" {
+		query = query.Where("threat_type = ?", filter.ThreatType)
+	}
+	if len(filter.Severities) > 0 {
+		query = query.Where("severity IN ?", filter.Severities)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("updated_at >= ?", filter.Since)
+	}
+	return query
+}
+
+// indicatorColumn 指标类型对应的数据库字段
+func indicatorColumn(indicatorType string) (string, error) {
+	switch indicatorType {
+	case "":
+		return "", nil
+	case IndicatorTypeIP:
+		return "ip_address", nil
+	case IndicatorTypeDomain:
+		return "domain", nil
+	case IndicatorTypeURL:
+		return "url", nil
+	case IndicatorTypeHash:
+		return "hash", nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedIndicatorType, indicatorType)
+}
+
+// buildThreatIndicators 将一条情报记录拆分为指标，indicatorType 不为空时只返回该类型
+func buildThreatIndicators(row Models.ThreatIntelligence, indicatorType string) []ThreatIndicator {
+	values := []struct{ kind, value string }{
+		{IndicatorTypeIP, row.IPAddress},
+		{IndicatorTypeDomain, row.Domain},
+		{IndicatorTypeURL, row.URL},
+		{IndicatorTypeHash, row.Hash},
+	}
+
+	// 置信度兼容0-1和0-100两种写法
+	confidence := row.Confidence
+	if confidence <= 1 {
+		confidence *= 100
+	}
+
+	var tags []string
+	for _, tag := range strings.Split(row.Tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	var indicators []ThreatIndicator
+	for _, v := range values {
+		if v.value == "" || (indicatorType != "" && indicatorType != v.kind) {
+			continue
+		}
+		indicators = append(indicators, ThreatIndicator{
+			ID:          row.ID,
+			Type:        v.kind,
+			Value:       v.value,
+			ThreatType:  row.ThreatType,
+			Severity:    row.Severity,
+			Confidence:  int(confidence + 0.5),
+			Source:      row.Source,
+			Description: row.Description,
+			Tags:        tags,
+			FirstSeen:   row.FirstSeen,
+			LastSeen:    row.LastSeen,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+		})
+	}
+	return indicators
+}
+
+// indicatorEncoder 流式指标编码器
+type indicatorEncoder interface {
+	begin() error
+	write(indicator ThreatIndicator) error
+	end(result *ThreatIntelExportResult) error
+}
+
+// newIndicatorEncoder 创建指定格式的编码器
+func newIndicatorEncoder(format string, w io.Writer) indicatorEncoder {
+	switch format {
+	case ThreatIntelFormatCSV:
+		return &csvIndicatorEncoder{writer: csv.NewWriter(w)}
+	case ThreatIntelFormatSTIX:
+		return &stixIndicatorEncoder{w: w}
+	default:
+		return &jsonIndicatorEncoder{w: w}
+	}
+}
+
+// jsonIndicatorEncoder JSON格式：{"indicators":[...],"count":N,"next_cursor":N,"exported_at":"..."}
+type jsonIndicatorEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonIndicatorEncoder) begin() error {
+	_, err := io.WriteString(e.w, `{"indicators":[`)
+	return err
+}
+
+func (e *jsonIndicatorEncoder) write(indicator ThreatIndicator) error {
+	data, err := json.Marshal(indicator)
+	if err != nil {
+		return err
+	}
+	if e.count > 0 {
+		data = append([]byte{','}, data...)
+	}
+	e.count++
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *jsonIndicatorEncoder) end(result *ThreatIntelExportResult) error {
+	_, err := fmt.Fprintf(e.w, `],"count":%d,"next_cursor":%d,"exported_at":%q}`, e.count, result.NextCursor, time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+// csvIndicatorEncoder CSV格式，第一行为表头，标签以分号分隔
+type csvIndicatorEncoder struct {
+	writer *csv.Writer
+}
+
+func (e *csvIndicatorEncoder) begin() error {
+	return e.writer.Write([]string{"id", "type", "value", "threat_type", "severity", "confidence", "source", "description", "tags", "first_seen", "last_seen", "updated_at"})
+}
+
+func (e *csvIndicatorEncoder) write(indicator ThreatIndicator) error {
+	return e.writer.Write([]string{
+		strconv.FormatUint(uint64(indicator.ID), 10),
+		indicator.Type,
+		indicator.Value,
+		indicator.ThreatType,
+		indicator.Severity,
+		strconv.Itoa(indicator.Confidence),
+		indicator.Source,
+		indicator.Description,
+		strings.Join(indicator.Tags, ";"),
+		formatCSVTime(indicator.FirstSeen),
+		formatCSVTime(indicator.LastSeen),
+		formatCSVTime(indicator.UpdatedAt),
+	})
+}
+
+func (e *csvIndicatorEncoder) end(result *ThreatIntelExportResult) error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// formatCSVTime 零值时间输出为空
+func formatCSVTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// stixIndicatorEncoder STIX 2.1 Bundle格式，每个指标对应一个 indicator 对象
+type stixIndicatorEncoder struct {
+	w     io.Writer
+	count int
+}
+
+// STIXIndicator STIX 2.1 Indicator对象
+type STIXIndicator struct {
+	Type           string   `json:"type"`
+	SpecVersion    string   `json:"spec_version"`
+	ID             string   `json:"id"`
+	Created        string   `json:"created"`
+	Modified       string   `json:"modified"`
+	Name           string   `json:"name"`
+	Description    string   `json:"description,omitempty"`
+	IndicatorTypes []string `json:"indicator_types"`
+	Pattern        string   `json:"pattern"`
+	PatternType    string   `json:"pattern_type"`
+	ValidFrom      string   `json:"valid_from"`
+	Confidence     int      `json:"confidence"`
+	Labels         []string `json:"labels,omitempty"`
+	XSeverity      string   `json:"x_severity,omitempty"`
+	XThreatType    string   `json:"x_threat_type,omitempty"`
+	XSource        string   `json:"x_source,omitempty"`
+}
+
+func (e *stixIndicatorEncoder) begin() error {
+	_, err := fmt.Fprintf(e.w, `{"type":"bundle","id":"bundle--%s","objects":[`, uuid.New().String())
+	return err
+}
+
+func (e *stixIndicatorEncoder) write(indicator ThreatIndicator) error {
+	data, err := json.Marshal(NewSTIXIndicator(indicator))
+	if err != nil {
+		return err
+	}
+	if e.count > 0 {
+		data = append([]byte{','}, data...)
+	}
+	e.count++
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *stixIndicatorEncoder) end(result *ThreatIntelExportResult) error {
+	_, err := io.WriteString(e.w, `]}`)
+	return err
+}
+
+// NewSTIXIndicator 将指标转换为STIX 2.1 Indicator对象
+func NewSTIXIndicator(indicator ThreatIndicator) STIXIndicator {
+	created := indicator.CreatedAt
+	if created.IsZero() {
+		created = time.Now()
+	}
+	modified := indicator.UpdatedAt
+	if modified.Before(created) {
+		modified = created
+	}
+	validFrom := indicator.FirstSeen
+	if validFrom.IsZero() {
+		validFrom = created
+	}
+
+	return STIXIndicator{
+		Type:           "indicator",
+		SpecVersion:    "2.1",
+		ID:             "indicator--" + uuid.NewSHA1(stixNamespace, []byte(indicator.Type+":"+indicator.Value)).String(),
+		Created:        stixTime(created),
+		Modified:       stixTime(modified),
+		Name:           fmt.Sprintf("%s: %s", indicator.Type, indicator.Value),
+		Description:    indicator.Description,
+		IndicatorTypes: []string{"malicious-activity"},
+		Pattern:        STIXPattern(indicator.Type, indicator.Value),
+		PatternType:    "stix",
+		ValidFrom:      stixTime(validFrom),
+		Confidence:     indicator.Confidence,
+		Labels:         indicator.Tags,
+		XSeverity:      indicator.Severity,
+		XThreatType:    indicator.ThreatType,
+		XSource:        indicator.Source,
+	}
+}
+
+// stixTime STIX要求的UTC毫秒时间格式
+func stixTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// STIXPattern 生成指标的STIX模式表达式
+func STIXPattern(indicatorType, value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+	switch indicatorType {
+	case IndicatorTypeIP:
+		if strings.Contains(value, ":") {
+			return fmt.Sprintf("[ipv6-addr:value = '%s']", escaped)
+		}
+		return fmt.Sprintf("[ipv4-addr:value = '%s']", escaped)
+	case IndicatorTypeDomain:
+		return fmt.Sprintf("[domain-name:value = '%s']", escaped)
+	case IndicatorTypeURL:
+		return fmt.Sprintf("[url:value = '%s']", escaped)
+	case IndicatorTypeHash:
+		algorithm := "SHA-256"
+		switch len(value) {
+		case 32:
+			algorithm = "MD5"
+		case 40:
+			algorithm = "SHA-1"
+		case 128:
+			algorithm = "SHA-512"
+		}
+		return fmt.Sprintf("[file:hashes.'%s' = '%s']", algorithm, escaped)
+	}
+	return fmt.Sprintf("[x-unknown:value = '%s']", escaped)
+}
@@ -0,0 +1,289 @@
+package Services
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// threatIntelShareDependency 威胁情报推送在出站HTTP客户端中的依赖名称
+const threatIntelShareDependency = "threat_intel_share"
+
+// ErrThreatIntelSharingDisabled 未启用威胁情报共享
+var ErrThreatIntelSharingDisabled = errors.New("威胁情报共享未启用")
+
+// ThreatIntelSharingStatus 威胁情报推送状态
+type ThreatIntelSharingStatus struct {
+	Enabled        bool      `json:"enabled"`
+	Running        bool      `json:"running"`
+	Format         string    `json:"format"`
+	Interval       string    `json:"interval"`
+	Cursor         uint      `json:"cursor"`
+	LastPushAt     time.Time `json:"last_push_at,omitempty"`
+	LastPushCount  int       `json:"last_push_count"`
+	LastError      string    `json:"last_error,omitempty"`
+	TotalPushed    int64     `json:"total_pushed"`
+	TotalRequests  int64     `json:"total_requests"`
+	FailedRequests int64     `json:"failed_requests"`
+}
+
+// ThreatIntelSharingService 威胁情报共享服务
+// 功能说明：
+// 1. 按推送间隔将新增的威胁情报（ID大于上次推送位置）推送到下游Webhook
+// 2. 每次请求最多包含 BatchSize 条记录，数据较多时分多次请求，推送成功后才前移游标
+// 3. 配置了密钥时在 X-Signature-256 头中携带请求体的HMAC-SHA256签名
+// 4. 首次推送从服务启动时的最新记录开始，历史情报通过导出接口获取
+type ThreatIntelSharingService struct {
+	config   *Config.ThreatIntelSharingConfig
+	exporter *ThreatIntelExporter
+	db       *gorm.DB
+	client   *OutboundHTTPClient
+
+	// pushMu 保证同一时间只有一次推送，避免重复发送
+	pushMu sync.Mutex
+	mu     sync.RWMutex
+	status ThreatIntelSharingStatus
+	// cursorReady 是否已确定推送起始位置
+	cursorReady bool
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	isRunning bool
+}
+
+// NewThreatIntelSharingService 创建威胁情报共享服务
+//
+// config 为 nil 时使用全局配置；client 为 nil 时使用全局出站HTTP客户端。
+func NewThreatIntelSharingService(db *gorm.DB, config *Config.ThreatIntelSharingConfig, client *OutboundHTTPClient) *ThreatIntelSharingService {
+	if config == nil {
+		if globalConfig := Config.GetConfig(); globalConfig != nil {
+			config = &globalConfig.Security.ThreatIntelSharing
+		} else {
+			securityConfig := &Config.SecurityConfig{}
+			securityConfig.SetDefaults()
+			config = &securityConfig.ThreatIntelSharing
+		}
+	}
+	if client == nil {
+		client = GetOutboundHTTPClient()
+	}
+
+	return &ThreatIntelSharingService{
+		config:   config,
+		exporter: NewThreatIntelExporter(db),
+		db:       db,
+		client:   client,
+	}
+}
+
+// Start 启动定时推送
+func (s *ThreatIntelSharingService) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isRunning {
+		return fmt.Errorf("威胁情报共享服务已在运行")
+	}
+	if !s.config.Enabled {
+		return nil
+	}
+
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.isRunning = true
+	go s.pushLoop(s.ctx)
+	return nil
+}
+
+// Stop 停止定时推送
+func (s *ThreatIntelSharingService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isRunning {
+		return
+	}
+	s.cancel()
+	s.isRunning = false
+}
+
+// pushLoop 按推送间隔推送新增情报
+func (s *ThreatIntelSharingService) pushLoop(ctx context.Context) {
+	// 启动时确定起始位置，之后新增的情报才会推送
+	if err := s.initCursor(ctx); err != nil {
+		log.Printf("初始化威胁情报推送位置失败: %v", err)
+	}
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Push(ctx); err != nil {
+				log.Printf("推送威胁情报失败: %v", err)
+			}
+		}
+	}
+}
+
+// initCursor 以当前最大ID作为推送起始位置
+func (s *ThreatIntelSharingService) initCursor(ctx context.Context) error {
+	s.mu.RLock()
+	ready := s.cursorReady
+	s.mu.RUnlock()
+	if ready {
+		return nil
+	}
+
+	var maxID uint
+	if err := s.db.WithContext(ctx).Model(&Models.ThreatIntelligence{}).Select("COALESCE(MAX(id), 0)").Scan(&maxID).Error; err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.cursorReady {
+		s.status.Cursor = maxID
+		s.cursorReady = true
+	}
+	return nil
+}
+
+// SetCursor 设置推送位置，下次推送从ID大于 cursor 的情报开始
+func (s *ThreatIntelSharingService) SetCursor(cursor uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Cursor = cursor
+	s.cursorReady = true
+}
+
+// Push 立即推送新增情报，返回推送的指标数
+func (s *ThreatIntelSharingService) Push(ctx context.Context) (int, error) {
+	if !s.config.Enabled || s.config.WebhookURL == "" {
+		return 0, ErrThreatIntelSharingDisabled
+	}
+
+	s.pushMu.Lock()
+	defer s.pushMu.Unlock()
+
+	if err := s.initCursor(ctx); err != nil {
+		return 0, s.finishPush(0, fmt.Errorf("初始化推送位置失败: %v", err))
+	}
+
+	pushed := 0
+	for {
+		s.mu.RLock()
+		cursor := s.status.Cursor
+		s.mu.RUnlock()
+
+		var body bytes.Buffer
+		result, err := s.exporter.Export(ctx, &body, s.config.Format, ThreatIntelExportFilter{
+			AfterID: cursor,
+			Limit:   s.config.BatchSize,
+		})
+		if err != nil {
+			return pushed, s.finishPush(pushed, err)
+		}
+		if result.Records == 0 {
+			break
+		}
+
+		if err := s.send(ctx, body.Bytes()); err != nil {
+			return pushed, s.finishPush(pushed, err)
+		}
+
+		pushed += result.Indicators
+		s.mu.Lock()
+		s.status.Cursor = result.LastID
+		s.status.TotalPushed += int64(result.Indicators)
+		s.mu.Unlock()
+
+		if result.NextCursor == 0 {
+			break
+		}
+	}
+
+	return pushed, s.finishPush(pushed, nil)
+}
+
+// send 发送一批情报到Webhook
+func (s *ThreatIntelSharingService) send(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建推送请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", ThreatIntelContentType(s.config.Format))
+	req.Header.Set("X-Threat-Intel-Format", s.config.Format)
+	if s.config.Secret != "" {
+		req.Header.Set("X-Signature-256", SignThreatIntelPayload(s.config.Secret, body))
+	}
+
+	s.mu.Lock()
+	s.status.TotalRequests++
+	s.mu.Unlock()
+
+	resp, err := s.client.Do(threatIntelShareDependency, req)
+	if err == nil {
+		defer resp.Body.Close()
+		io.Copy(io.Discard, resp.Body)
+		if resp.StatusCode >= http.StatusBadRequest {
+			err = fmt.Errorf("Webhook返回错误状态: %s", resp.Status)
+		}
+	}
+	if err != nil {
+		s.mu.Lock()
+		s.status.FailedRequests++
+		s.mu.Unlock()
+		return fmt.Errorf("推送威胁情报失败: %w", err)
+	}
+	return nil
+}
+
+// finishPush 记录本次推送结果
+func (s *ThreatIntelSharingService) finishPush(pushed int, err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status.LastPushAt = time.Now()
+	s.status.LastPushCount = pushed
+	s.status.LastError = ""
+	if err != nil {
+		s.status.LastError = err.Error()
+	}
+	return err
+}
+
+// SignThreatIntelPayload 计算请求体签名，格式为 "sha256=<hex>"
+func SignThreatIntelPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// GetStatus 获取推送状态
+func (s *ThreatIntelSharingService) GetStatus() ThreatIntelSharingStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := s.status
+	status.Enabled = s.config.Enabled && s.config.WebhookURL != ""
+	status.Running = s.isRunning
+	status.Format = s.config.Format
+	status.Interval = s.config.Interval.String()
+	return status
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 )
 
@@ -204,3 +205,105 @@ func (s *TokenBlacklistService) GetBlacklistedTokenInfo(token string) (*Blacklis
 
 	return nil, fmt.Errorf("token not found in blacklist")
 }
+
+// userTokenMarks 用户级token标记的内存存储
+// 认证中间件和自动响应各自创建黑名单服务实例，没有Redis时通过包级存储共享标记
+type userTokenMarks struct {
+	mu    sync.Mutex
+	marks map[uint]userTokenMark
+}
+
+// userTokenMark 用户级标记，Since 之前签发的token受影响，ExpiresAt 后标记失效
+type userTokenMark struct {
+	Since     time.Time `json:"since"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+var (
+	revokedUserTokens = &userTokenMarks{marks: make(map[uint]userTokenMark)}
+	mfaRequiredUsers  = &userTokenMarks{marks: make(map[uint]userTokenMark)}
+)
+
+// RevokeUserTokens 撤销用户当前所有token（强制下线）
+// 功能说明：
+// 1. 撤销时间之前签发的token全部失效，之后重新登录获取的token不受影响
+// 2. ttl 应不短于token的最长有效期，过期后标记自动清除
+func (s *TokenBlacklistService) RevokeUserTokens(userID uint, ttl time.Duration) error {
+	return s.setUserMark("user_revoked", revokedUserTokens, userID, ttl)
+}
+
+// IsUserTokenRevoked 检查用户在 issuedAt 签发的token是否已被撤销
+func (s *TokenBlacklistService) IsUserTokenRevoked(userID uint, issuedAt time.Time) bool {
+	mark, ok := s.getUserMark("user_revoked", revokedUserTokens, userID)
+	// JWT签发时间只精确到秒，撤销当秒签发的token同样视为已撤销
+	return ok && !issuedAt.After(mark.Since)
+}
+
+// RequireMFAReverification 标记用户需要重新进行MFA验证
+func (s *TokenBlacklistService) RequireMFAReverification(userID uint, ttl time.Duration) error {
+	return s.setUserMark("mfa_required", mfaRequiredUsers, userID, ttl)
+}
+
+// IsMFAReverificationRequired 检查用户是否需要重新进行MFA验证
+func (s *TokenBlacklistService) IsMFAReverificationRequired(userID uint) bool {
+	_, ok := s.getUserMark("mfa_required", mfaRequiredUsers, userID)
+	return ok
+}
+
+// ClearMFAReverification 用户完成MFA验证后清除标记
+func (s *TokenBlacklistService) ClearMFAReverification(userID uint) error {
+	mfaRequiredUsers.mu.Lock()
+	delete(mfaRequiredUsers.marks, userID)
+	mfaRequiredUsers.mu.Unlock()
+
+	if s.redisService != nil {
+		return s.redisService.Del(context.Background(), fmt.Sprintf("mfa_required:%d", userID))
+	}
+	return nil
+}
+
+// setUserMark 写入用户级标记，Redis不可用时只写内存
+func (s *TokenBlacklistService) setUserMark(prefix string, store *userTokenMarks, userID uint, ttl time.Duration) error {
+	now := time.Now()
+	mark := userTokenMark{Since: now, ExpiresAt: now.Add(ttl)}
+
+	store.mu.Lock()
+	store.marks[userID] = mark
+	store.mu.Unlock()
+
+	if s.redisService != nil {
+		data, err := json.Marshal(mark)
+		if err != nil {
+			return err
+		}
+		if err := s.redisService.SetWithTTL(context.Background(), fmt.Sprintf("%s:%d", prefix, userID), string(data), ttl); err != nil {
+			return fmt.Errorf("redis failed, using memory storage: %v", err)
+		}
+	}
+	return nil
+}
+
+// getUserMark 读取用户级标记，优先Redis
+func (s *TokenBlacklistService) getUserMark(prefix string, store *userTokenMarks, userID uint) (userTokenMark, bool) {
+	if s.redisService != nil {
+		data, err := s.redisService.GetString(context.Background(), fmt.Sprintf("%s:%d", prefix, userID))
+		if err == nil && data != "" {
+			var mark userTokenMark
+			if err := json.Unmarshal([]byte(data), &mark); err == nil {
+				return mark, true
+			}
+		}
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	mark, ok := store.marks[userID]
+	if !ok {
+		return userTokenMark{}, false
+	}
+	if time.Now().After(mark.ExpiresAt) {
+		delete(store.marks, userID)
+		return userTokenMark{}, false
+	}
+	return mark, true
+}
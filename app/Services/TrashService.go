@@ -0,0 +1,221 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 回收站支持的类型，用于回收站接口路径
+const (
+	TrashUsers      = "users"
+	TrashAlertRules = "alert-rules"
+	TrashDashboards = "dashboards"
+	TrashApiKeys    = "api-keys"
+)
+
+var (
+	// ErrTrashTypeUnknown 不支持的回收站类型
+	ErrTrashTypeUnknown = errors.New("不支持的回收站类型")
+	// ErrTrashItemNotFound 回收站中没有该记录（记录不存在或未删除）
+	ErrTrashItemNotFound = errors.New("回收站中没有该记录")
+)
+
+// trashKind 回收站类型
+type trashKind struct {
+	model func() interface{} // 模型指针
+	list  func() interface{} // 模型切片指针
+}
+
+// TrashService 回收站服务
+// 功能说明：
+// 1. 用户、告警规则、监控仪表板和API密钥使用软删除，删除后进入回收站
+// 2. 管理员可以列出回收站中的记录、恢复记录或彻底删除记录
+// 3. 恢复和彻底删除由 DeletionService 按关联数据的删除策略执行：恢复一同删除的关联数据，彻底删除时删除或置空关联数据
+// 4. 删除时间超过保留时间的记录由 StartPurger 定期彻底删除
+type TrashService struct {
+	db            *gorm.DB
+	deletion      *DeletionService
+	retention     time.Duration
+	purgeInterval time.Duration
+	purgeBatch    int
+	kinds         map[string]trashKind
+	now           func() time.Time
+}
+
+// NewTrashService 创建回收站服务，config 为 nil 时保留30天、每小时清理一次
+func NewTrashService(db *gorm.DB, config *Config.TrashConfig) *TrashService {
+	service := &TrashService{
+		db:            db,
+		deletion:      NewDeletionService(db, config),
+		retention:     30 * 24 * time.Hour,
+		purgeInterval: time.Hour,
+		purgeBatch:    500,
+		now:           time.Now,
+		kinds: map[string]trashKind{
+			TrashUsers: {
+				model: func() interface{} { return &Models.User{} },
+				list:  func() interface{} { return &[]Models.User{} },
+			},
+			TrashAlertRules: {
+				model: func() interface{} { return &Models.AlertRule{} },
+				list:  func() interface{} { return &[]Models.AlertRule{} },
+			},
+			TrashDashboards: {
+				model: func() interface{} { return &Models.MonitoringDashboard{} },
+				list:  func() interface{} { return &[]Models.MonitoringDashboard{} },
+			},
+			TrashApiKeys: {
+				model: func() interface{} { return &Models.ApiKey{} },
+				list:  func() interface{} { return &[]Models.ApiKey{} },
+			},
+		},
+	}
+	if config != nil {
+		service.retention = config.Retention
+		if config.PurgeInterval > 0 {
+			service.purgeInterval = config.PurgeInterval
+		}
+		if config.PurgeBatch > 0 {
+			service.purgeBatch = config.PurgeBatch
+		}
+	}
+	return service
+}
+
+// SetClock 设置计算清理截止时间使用的时钟
+func (s *TrashService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// Types 支持的回收站类型
+func (s *TrashService) Types() []string {
+	types := make([]string, 0, len(s.kinds))
+	for name := range s.kinds {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// kind 查找回收站类型
+func (s *TrashService) kind(name string) (trashKind, error) {
+	kind, ok := s.kinds[name]
+	if !ok {
+		return kind, fmt.Errorf("%w：%s", ErrTrashTypeUnknown, name)
+	}
+	return kind, nil
+}
+
+// trashed 回收站中记录的查询
+func (s *TrashService) trashed(tx *gorm.DB, kind trashKind) *gorm.DB {
+	return tx.Unscoped().Model(kind.model()).Where("deleted_at IS NOT NULL")
+}
+
+// List 分页列出回收站中的记录，按ID倒序
+func (s *TrashService) List(name string, req Utils.PageRequest) (interface{}, Utils.PageMeta, error) {
+	kind, err := s.kind(name)
+	if err != nil {
+		return nil, Utils.PageMeta{}, err
+	}
+	dest := kind.list()
+	meta, err := Utils.Paginate(s.trashed(s.db, kind), req, Utils.PageOrder{}, dest)
+	return reflect.ValueOf(dest).Elem().Interface(), meta, err
+}
+
+// Restore 恢复回收站中的记录及与其一同删除的关联数据
+func (s *TrashService) Restore(name string, id uint) error {
+	if _, err := s.kind(name); err != nil {
+		return err
+	}
+	return s.deletion.Restore(name, id)
+}
+
+// ForceDelete 彻底删除回收站中的记录并按删除策略处理关联数据，未删除的记录需要先删除进入回收站
+func (s *TrashService) ForceDelete(name string, id uint) error {
+	kind, err := s.kind(name)
+	if err != nil {
+		return err
+	}
+	var count int64
+	if err := s.trashed(s.db, kind).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrTrashItemNotFound
+	}
+	return s.deletion.ForceDelete(name, []uint{id})
+}
+
+// Purge 彻底删除删除时间超过保留时间的记录，返回每种类型删除的数量
+// 每种类型每次最多删除 purgeBatch 条，未删完的在下次清理时继续
+func (s *TrashService) Purge(ctx context.Context) (map[string]int64, error) {
+	purged := make(map[string]int64)
+	if s.retention <= 0 {
+		return purged, nil
+	}
+	cutoff := s.now().Add(-s.retention)
+	for _, name := range s.Types() {
+		kind := s.kinds[name]
+		var ids []uint
+		if err := s.trashed(s.db.WithContext(ctx), kind).Where("deleted_at < ?", cutoff).
+			Order("id").Limit(s.purgeBatch).Pluck("id", &ids).Error; err != nil {
+			return purged, fmt.Errorf("查询过期的%s失败: %v", name, err)
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		err := s.deletion.ForceDelete(name, ids)
+		if err == nil {
+			purged[name] = int64(len(ids))
+			continue
+		}
+		if !errors.Is(err, ErrDeletionRestricted) {
+			return purged, fmt.Errorf("清理过期的%s失败: %v", name, err)
+		}
+		// 存在 restrict 关联数据（如用户的文章）的记录保留在回收站中，逐条删除其余记录
+		for _, id := range ids {
+			if err := s.deletion.ForceDelete(name, []uint{id}); err == nil {
+				purged[name]++
+			} else if !errors.Is(err, ErrDeletionRestricted) {
+				return purged, fmt.Errorf("清理过期的%s失败: %v", name, err)
+			}
+		}
+	}
+	return purged, nil
+}
+
+// StartPurger 启动后台清理，保留时间为0时不启动
+func (s *TrashService) StartPurger(ctx context.Context) {
+	if s.retention <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.purgeInterval)
+		defer ticker.Stop()
+
+		for {
+			if purged, err := s.Purge(ctx); err != nil {
+				log.Printf("清理回收站失败: %v", err)
+			} else if len(purged) > 0 {
+				log.Printf("已彻底删除回收站中过期的记录: %v", purged)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,126 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Models"
+	"context"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// UserNotification 发送给用户的通知，各渠道的内容为空时不通过该渠道发送
+type UserNotification struct {
+	Type    string                 // 站内通知类型，为空时不发送站内通知
+	Title   string                 // 站内通知标题
+	Content string                 // 站内通知内容
+	Data    map[string]interface{} // 站内通知附加数据
+
+	EmailSubject string // 邮件主题，为空时不发送邮件
+	EmailBody    string // HTML邮件正文
+
+	SMSTemplate string            // 短信模板名称，为空时不发送短信
+	SMSVars     map[string]string // 短信模板变量
+}
+
+// UserNotificationResult 通知发送结果
+type UserNotificationResult struct {
+	Delivered []string         // 发送成功的渠道
+	OptedOut  []string         // 用户关闭了通知的渠道
+	Failures  map[string]error // 发送失败的渠道
+}
+
+// UserNotificationDispatcher 按用户偏好发送通知
+// 功能说明：
+// 1. 同一条通知可以通过站内通知、邮件、短信发送，用户在偏好设置中关闭的渠道跳过
+// 2. 没有邮箱、未绑定手机号或对应服务未配置时跳过该渠道，不算失败
+// 3. 账户锁定解锁链接、模拟登录提醒等安全通知不经过该分发器，始终发送
+// 4. 启用用量计量时邮件和短信计入用户的通知发送用量，超过本周期限额的渠道不再发送，记为失败
+type UserNotificationDispatcher struct {
+	settings      *UserSettingsService
+	notifications *NotificationService
+	mailer        NotificationMailer
+	sms           *SMSService
+	metering      *MeteringService
+}
+
+// NewUserNotificationDispatcher 创建用户通知分发器
+//
+// mailer 为 nil 时使用全局邮件服务；站内通知和短信使用全局服务，可通过 SetNotificationService、SetSMSService 替换。
+func NewUserNotificationDispatcher(db *gorm.DB, mailer NotificationMailer) *UserNotificationDispatcher {
+	if mailer == nil {
+		mailer = defaultNotificationMailer()
+	}
+	return &UserNotificationDispatcher{
+		settings:      DefaultUserSettingsService(db),
+		notifications: DefaultNotificationService(),
+		mailer:        mailer,
+		sms:           DefaultSMSService(),
+		metering:      DefaultMeteringService(),
+	}
+}
+
+// SetNotificationService 设置站内通知服务
+func (d *UserNotificationDispatcher) SetNotificationService(service *NotificationService) {
+	d.notifications = service
+}
+
+// SetSMSService 设置短信服务
+func (d *UserNotificationDispatcher) SetSMSService(service *SMSService) {
+	d.sms = service
+}
+
+// SetMeteringService 设置用量计量服务，为 nil 时不统计通知发送用量
+func (d *UserNotificationDispatcher) SetMeteringService(service *MeteringService) {
+	d.metering = service
+}
+
+// Dispatch 按用户偏好同步发送通知
+func (d *UserNotificationDispatcher) Dispatch(ctx context.Context, user *Models.User, notification UserNotification) UserNotificationResult {
+	result := UserNotificationResult{Failures: make(map[string]error)}
+
+	settings := d.settings.Defaults()
+	if saved, err := d.settings.Get(user.ID); err != nil {
+		log.Printf("读取用户偏好设置失败，使用默认设置: user=%d, error=%v", user.ID, err)
+	} else {
+		settings = saved
+	}
+
+	// metered 为 true 的渠道计入通知发送用量
+	send := func(channel string, available, metered bool, deliver func() error) {
+		if !available {
+			return
+		}
+		if !d.settings.ChannelEnabled(settings, channel) {
+			result.OptedOut = append(result.OptedOut, channel)
+			return
+		}
+		metered = metered && d.metering != nil
+		if metered {
+			if err := d.metering.Check(UserTenant(user.ID), UsageMetricNotificationsSent, 1); err != nil {
+				result.Failures[channel] = err
+				return
+			}
+		}
+		if err := deliver(); err != nil {
+			result.Failures[channel] = err
+			return
+		}
+		if metered {
+			d.metering.Record(UserTenant(user.ID), UsageMetricNotificationsSent, 1)
+		}
+		result.Delivered = append(result.Delivered, channel)
+	}
+
+	send(NotificationChannelInApp, notification.Type != "" && d.notifications != nil, false, func() error {
+		_, err := d.notifications.Notify(user.ID, notification.Type, notification.Title, notification.Content, notification.Data)
+		return err
+	})
+	send(NotificationChannelEmail, notification.EmailSubject != "" && user.Email != "" && d.mailer != nil, true, func() error {
+		return d.mailer.SendNotificationEmail(user.Email, notification.EmailSubject, notification.EmailBody)
+	})
+	send(NotificationChannelSMS, notification.SMSTemplate != "" && user.Phone != "" && d.sms != nil, true, func() error {
+		_, err := d.sms.Send(ctx, user.Phone, notification.SMSTemplate, notification.SMSVars)
+		return err
+	})
+	return result
+}
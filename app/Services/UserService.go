@@ -179,7 +179,7 @@ func (s *UserService) UpdateUser(id uint, updates map[string]interface{}) (*Mode
 // 功能说明：
 // 1. 根据用户ID删除用户
 // 2. 验证用户是否存在
-// 3. 执行软删除或硬删除（取决于模型配置）
+// 3. 通过 DeletionService 执行软删除，用户及其API密钥等关联数据进入回收站
 //
 // 参数说明：
 // - id: 用户ID（必须存在）
@@ -188,8 +188,8 @@ func (s *UserService) UpdateUser(id uint, updates map[string]interface{}) (*Mode
 // - error: 错误信息（如果用户不存在或删除失败）
 //
 // 删除策略：
-// - 如果模型支持软删除，会执行软删除（设置DeletedAt）
-// - 如果模型不支持软删除，会执行硬删除（物理删除）
+// - 用户有未删除的文章时拒绝删除（ErrDeletionRestricted）
+// - 关联数据的删除策略见 DeletionService
 // - 软删除的数据可以通过Unscoped查询
 //
 // 使用场景：
@@ -199,7 +199,7 @@ func (s *UserService) UpdateUser(id uint, updates map[string]interface{}) (*Mode
 //
 // 注意事项：
 // - 用户必须存在才能删除
-// - 删除操作不可逆（硬删除）
+// - 删除后可以在回收站中恢复，超过保留时间后彻底删除
 // - 软删除的数据仍然占用数据库空间
 // - 删除前应该检查是否有关联数据
 // - 建议使用软删除，保留数据用于审计
@@ -215,8 +215,8 @@ func (s *UserService) DeleteUser(id uint) error {
 	}
 
 	// 删除用户
-	// 根据模型配置执行软删除或硬删除
-	return s.getDB().Delete(&user).Error
+	// 用户和关联数据进入回收站，删除后撤销用户已签发的token
+	return DefaultDeletionService(s.getDB()).Delete(TrashUsers, user.ID)
 }
 
 // CreateUser 创建用户
@@ -264,8 +264,14 @@ func (s *UserService) CreateUser(user *Models.User) (*Models.User, error) {
 	}
 
 	// 创建用户
-	// 将用户保存到数据库，GORM会自动处理时间戳
-	if err := s.getDB().Create(user).Error; err != nil {
+	// 将用户保存到数据库，GORM会自动处理时间戳；同一事务中写入用户创建事件
+	err := s.getDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return publishUserCreated(tx, user, "admin")
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -276,6 +282,18 @@ func (s *UserService) CreateUser(user *Models.User) (*Models.User, error) {
 	return user, nil
 }
 
+// publishUserCreated 在创建用户的事务中发布用户创建事件，source 为创建方式（register/admin/bulk_import）
+func publishUserCreated(tx *gorm.DB, user *Models.User, source string) error {
+	return PublishDomainEvent(tx, EventUserCreated, "user", fmt.Sprint(user.ID), map[string]interface{}{
+		"user_id":  user.ID,
+		"username": user.Username,
+		"email":    user.Email,
+		"role":     user.Role,
+		"status":   user.Status,
+		"source":   source,
+	})
+}
+
 // GetUserByID 根据ID获取用户
 // 功能说明：
 // 1. 根据用户ID获取用户信息
@@ -285,6 +303,23 @@ func (s *UserService) GetUserByID(id uint) (*Models.User, error) {
 	return s.GetUser(id)
 }
 
+// GetPreferredLocale 获取用户偏好语言
+// 功能说明：
+// 1. 只查询locale字段，供语言解析使用
+// 2. 用户不存在、未设置或数据库不可用时返回空字符串
+func (s *UserService) GetPreferredLocale(userID string) string {
+	db := s.getDB()
+	if db == nil || userID == "" {
+		return ""
+	}
+
+	var user Models.User
+	if err := db.Select("id", "locale").Where("id = ?", userID).Take(&user).Error; err != nil {
+		return ""
+	}
+	return user.Locale
+}
+
 // GetUserByUsername 根据用户名获取用户
 // 功能说明：
 // 1. 根据用户名获取用户信息
@@ -444,12 +479,8 @@ func (s *UserService) ChangePassword(userID uint, oldPassword, newPassword strin
 		return fmt.Errorf("密码哈希失败: %v", err)
 	}
 
-	// 更新密码
-	updates := map[string]interface{}{
-		"password": hashedPassword,
-	}
-
-	return s.getDB().Model(&user).Updates(updates).Error
+	// 更新密码，同时重置密码过期标记
+	return s.getDB().Model(&user).Updates(passwordChangedUpdates(hashedPassword)).Error
 }
 
 // ValidateUser 验证用户
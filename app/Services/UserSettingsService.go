@@ -0,0 +1,368 @@
+package Services
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/I18n"
+	"cloud-platform-api/app/Models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 用户设置项
+const (
+	SettingNotifyInApp             = "notifications.in_app"      // 接收站内通知
+	SettingNotifyEmail             = "notifications.email"       // 接收邮件通知
+	SettingNotifySMS               = "notifications.sms"         // 接收短信通知
+	SettingLocale                  = "locale"                    // 界面和通知语言
+	SettingTimezone                = "timezone"                  // 时区
+	SettingDashboardDefault        = "dashboard.default_id"      // 默认打开的监控仪表板，0表示不指定
+	SettingDashboardTimeRange      = "dashboard.time_range"      // 仪表板默认时间范围
+	SettingDashboardRefreshSeconds = "dashboard.refresh_seconds" // 仪表板自动刷新间隔（秒），0表示不自动刷新
+)
+
+// 用户通知渠道
+const (
+	NotificationChannelInApp = "in_app"
+	NotificationChannelEmail = "email"
+	NotificationChannelSMS   = "sms"
+)
+
+// notificationChannelSettings 通知渠道对应的设置项
+var notificationChannelSettings = map[string]string{
+	NotificationChannelInApp: SettingNotifyInApp,
+	NotificationChannelEmail: SettingNotifyEmail,
+	NotificationChannelSMS:   SettingNotifySMS,
+}
+
+var (
+	// ErrUnknownSetting 未知的设置项
+	ErrUnknownSetting = errors.New("未知的设置项")
+	// ErrInvalidSetting 设置值类型或取值无效
+	ErrInvalidSetting = errors.New("设置值无效")
+)
+
+// 设置值类型
+const (
+	SettingTypeBool   = "bool"
+	SettingTypeString = "string"
+	SettingTypeInt    = "int"
+)
+
+// SettingDefinition 设置项定义
+type SettingDefinition struct {
+	Key         string      `json:"key"`
+	Type        string      `json:"type"` // bool、string、int
+	Description string      `json:"description"`
+	Default     interface{} `json:"default"`           // 配置中的默认值
+	Options     []string    `json:"options,omitempty"` // 字符串设置的可选值，为空时不限制
+	Min         *int        `json:"min,omitempty"`     // 整数设置的最小值
+	Max         *int        `json:"max,omitempty"`     // 整数设置的最大值
+
+	column   string                        // 保存在 users 表的字段，为空时保存在 user_settings 表
+	clean    func(value string) string     // 校验可选值前规范化字符串，如语言标签
+	validate func(value interface{}) error // 类型和范围之外的额外校验
+}
+
+// normalize 将JSON解码得到的值转换为设置项的类型并校验取值
+func (d *SettingDefinition) normalize(value interface{}) (interface{}, error) {
+	invalid := func(reason string) error {
+		return fmt.Errorf("%w: %s %s", ErrInvalidSetting, d.Key, reason)
+	}
+
+	switch d.Type {
+	case SettingTypeBool:
+		if _, ok := value.(bool); !ok {
+			return nil, invalid("必须是布尔值")
+		}
+	case SettingTypeString:
+		s, ok := value.(string)
+		if !ok {
+			return nil, invalid("必须是字符串")
+		}
+		s = strings.TrimSpace(s)
+		if d.clean != nil {
+			s = d.clean(s)
+		}
+		value = s
+		if len(d.Options) > 0 && !slices.Contains(d.Options, s) {
+			return nil, invalid("可选值为 " + strings.Join(d.Options, ","))
+		}
+	case SettingTypeInt:
+		var n int
+		switch v := value.(type) {
+		case float64:
+			if v != math.Trunc(v) || math.Abs(v) > math.MaxInt32 {
+				return nil, invalid("必须是整数")
+			}
+			n = int(v)
+		case int:
+			n = v
+		default:
+			return nil, invalid("必须是整数")
+		}
+		if d.Min != nil && n < *d.Min {
+			return nil, invalid(fmt.Sprintf("不能小于 %d", *d.Min))
+		}
+		if d.Max != nil && n > *d.Max {
+			return nil, invalid(fmt.Sprintf("不能大于 %d", *d.Max))
+		}
+		value = n
+	}
+
+	if d.validate != nil {
+		if err := d.validate(value); err != nil {
+			return nil, invalid(err.Error())
+		}
+	}
+	return value, nil
+}
+
+// UserSettingsService 用户偏好设置服务
+// 功能说明：
+// 1. 设置项有固定的键、类型和可选值，默认值来自配置，用户只保存修改过的设置项
+// 2. 修改时先校验全部设置项再一次性保存，任一项无效时都不保存
+// 3. 语言保存在 users.locale 字段，与请求语言解析使用的偏好语言一致
+// 4. 向用户发送通知时按通知渠道开关决定是否发送，如用户关闭短信通知后不再向其发送短信
+type UserSettingsService struct {
+	db          *gorm.DB
+	definitions []SettingDefinition
+}
+
+// NewUserSettingsService 创建用户偏好设置服务
+//
+// config、i18nConfig 为 nil 时使用全局配置，全局配置未加载时使用默认值。
+func NewUserSettingsService(db *gorm.DB, config *Config.UserSettingsConfig, i18nConfig *Config.I18nConfig) *UserSettingsService {
+	if config == nil {
+		if config = Config.GetUserSettingsConfig(); config == nil {
+			config = &Config.UserSettingsConfig{
+				NotifyInApp:             true,
+				NotifyEmail:             true,
+				Timezone:                "Asia/Shanghai",
+				DashboardTimeRange:      "24h",
+				DashboardRefreshSeconds: 60,
+			}
+		}
+	}
+	if i18nConfig == nil {
+		if globalConfig := Config.GetConfig(); globalConfig != nil {
+			i18nConfig = &globalConfig.I18n
+		} else {
+			i18nConfig = &Config.I18nConfig{DefaultLocale: "zh-CN", Supported: []string{"zh-CN", "en"}}
+		}
+	}
+
+	var locales []string
+	for _, locale := range i18nConfig.Supported {
+		// 环境变量以逗号分隔的字符串传入时 viper 不会拆分
+		for _, item := range strings.Split(locale, ",") {
+			if item = I18n.NormalizeLocale(strings.TrimSpace(item)); item != "" {
+				locales = append(locales, item)
+			}
+		}
+	}
+
+	zero, maxRefresh := 0, 3600
+	return &UserSettingsService{
+		db: db,
+		definitions: []SettingDefinition{
+			{Key: SettingNotifyInApp, Type: SettingTypeBool, Description: "接收站内通知", Default: config.NotifyInApp},
+			{Key: SettingNotifyEmail, Type: SettingTypeBool, Description: "接收邮件通知", Default: config.NotifyEmail},
+			{Key: SettingNotifySMS, Type: SettingTypeBool, Description: "接收短信通知（需绑定手机号）", Default: config.NotifySMS},
+			{Key: SettingLocale, Type: SettingTypeString, Description: "界面和通知语言", Default: I18n.NormalizeLocale(i18nConfig.DefaultLocale), Options: locales, column: "locale",
+				clean: func(value string) string {
+					// 与注册时相同，按语言匹配，如 en-US 匹配 en
+					if locale := I18n.MatchLocale([]string{value}, locales); locale != "" {
+						return locale
+					}
+					return value
+				}},
+			{Key: SettingTimezone, Type: SettingTypeString, Description: "时区（IANA时区名，如 Asia/Shanghai）", Default: config.Timezone,
+				validate: func(value interface{}) error {
+					if _, err := time.LoadLocation(value.(string)); value == "" || err != nil {
+						return errors.New("不是有效的时区")
+					}
+					return nil
+				}},
+			{Key: SettingDashboardDefault, Type: SettingTypeInt, Description: "默认打开的监控仪表板ID，0表示不指定", Default: 0, Min: &zero},
+			{Key: SettingDashboardTimeRange, Type: SettingTypeString, Description: "仪表板默认时间范围", Default: config.DashboardTimeRange, Options: Config.DashboardTimeRanges},
+			{Key: SettingDashboardRefreshSeconds, Type: SettingTypeInt, Description: "仪表板自动刷新间隔（秒），0表示不自动刷新", Default: config.DashboardRefreshSeconds, Min: &zero, Max: &maxRefresh},
+		},
+	}
+}
+
+// Definitions 返回全部设置项定义
+func (s *UserSettingsService) Definitions() []SettingDefinition {
+	return s.definitions
+}
+
+// definition 查找设置项定义
+func (s *UserSettingsService) definition(key string) (*SettingDefinition, error) {
+	for i := range s.definitions {
+		if s.definitions[i].Key == key {
+			return &s.definitions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownSetting, key)
+}
+
+// Get 获取用户的全部设置，未设置或已保存的值不再有效时使用默认值
+func (s *UserSettingsService) Get(userID uint) (map[string]interface{}, error) {
+	var saved []Models.UserSetting
+	if err := s.db.Where("user_id = ?", userID).Find(&saved).Error; err != nil {
+		return nil, err
+	}
+
+	settings := s.Defaults()
+	if columns := s.userColumns(); len(columns) > 0 {
+		var user Models.User
+		if err := s.db.Select(append([]string{"id"}, columns...)).Where("id = ?", userID).Take(&user).Error; err != nil {
+			return nil, err
+		}
+		// 目前只有语言保存在 users 表
+		if definition, _ := s.definition(SettingLocale); user.Locale != "" {
+			if value, err := definition.normalize(user.Locale); err == nil {
+				settings[SettingLocale] = value
+			}
+		}
+	}
+	for _, setting := range saved {
+		definition, err := s.definition(setting.Key)
+		if err != nil || definition.column != "" {
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(setting.Value), &value); err != nil {
+			continue
+		}
+		// 可选值变化（如移除了某个语言）后已保存的值失效，使用默认值
+		if value, err = definition.normalize(value); err == nil {
+			settings[setting.Key] = value
+		}
+	}
+	return settings, nil
+}
+
+// Update 修改用户设置，返回修改后的全部设置
+func (s *UserSettingsService) Update(userID uint, values map[string]interface{}) (map[string]interface{}, error) {
+	settings := make([]Models.UserSetting, 0, len(values))
+	columns := make(map[string]interface{})
+	for key, value := range values {
+		definition, err := s.definition(key)
+		if err != nil {
+			return nil, err
+		}
+		if value, err = definition.normalize(value); err != nil {
+			return nil, err
+		}
+		if definition.column != "" {
+			columns[definition.column] = value
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		settings = append(settings, Models.UserSetting{UserID: userID, Key: key, Value: string(encoded)})
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if len(columns) > 0 {
+			if err := tx.Model(&Models.User{}).Where("id = ?", userID).Updates(columns).Error; err != nil {
+				return err
+			}
+		}
+		if len(settings) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+		}).Create(&settings).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(userID)
+}
+
+// Reset 将设置项恢复为默认值，返回恢复后的全部设置
+func (s *UserSettingsService) Reset(userID uint, key string) (map[string]interface{}, error) {
+	definition, err := s.definition(key)
+	if err != nil {
+		return nil, err
+	}
+	if definition.column != "" {
+		if err := s.db.Model(&Models.User{}).Where("id = ?", userID).Update(definition.column, "").Error; err != nil {
+			return nil, err
+		}
+		return s.Get(userID)
+	}
+	if err := s.db.Where(&Models.UserSetting{UserID: userID, Key: key}).Delete(&Models.UserSetting{}).Error; err != nil {
+		return nil, err
+	}
+	return s.Get(userID)
+}
+
+// userColumns 返回保存在 users 表的设置字段
+func (s *UserSettingsService) userColumns() []string {
+	var columns []string
+	for _, definition := range s.definitions {
+		if definition.column != "" {
+			columns = append(columns, definition.column)
+		}
+	}
+	return columns
+}
+
+// ChannelEnabled 按 Get 返回的用户设置判断是否接收该渠道的通知
+func (s *UserSettingsService) ChannelEnabled(settings map[string]interface{}, channel string) bool {
+	key, ok := notificationChannelSettings[channel]
+	if !ok {
+		return false
+	}
+	enabled, _ := settings[key].(bool)
+	return enabled
+}
+
+// Defaults 返回全部设置的默认值
+func (s *UserSettingsService) Defaults() map[string]interface{} {
+	settings := make(map[string]interface{}, len(s.definitions))
+	for _, definition := range s.definitions {
+		settings[definition.Key] = definition.Default
+	}
+	return settings
+}
+
+var (
+	defaultUserSettingsService   *UserSettingsService
+	defaultUserSettingsServiceMu sync.RWMutex
+)
+
+// SetDefaultUserSettingsService 设置全局用户偏好设置服务
+func SetDefaultUserSettingsService(service *UserSettingsService) {
+	defaultUserSettingsServiceMu.Lock()
+	defer defaultUserSettingsServiceMu.Unlock()
+	defaultUserSettingsService = service
+}
+
+// DefaultUserSettingsService 获取全局用户偏好设置服务，未设置时使用 db 创建，db 为 nil 时返回 nil
+func DefaultUserSettingsService(db *gorm.DB) *UserSettingsService {
+	defaultUserSettingsServiceMu.RLock()
+	defer defaultUserSettingsServiceMu.RUnlock()
+	if defaultUserSettingsService != nil {
+		return defaultUserSettingsService
+	}
+	if db == nil {
+		return nil
+	}
+	return NewUserSettingsService(db, nil, nil)
+}
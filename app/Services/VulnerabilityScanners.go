@@ -0,0 +1,785 @@
+package Services
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 扫描插件名称
+const (
+	ScannerDependencyCVE = "dependency_cve"
+	ScannerTLSConfig     = "tls_config"
+	ScannerJWTSecret     = "jwt_secret"
+	ScannerCORSPolicy    = "cors_policy"
+	ScannerOpenPorts     = "open_ports"
+	ScannerAPIKeyExpiry  = "api_key_expiry"
+)
+
+// VulnerabilityScanner 漏洞扫描插件
+// 功能说明：
+// 1. Name 返回插件名称，记录在发现的漏洞和插件错误中
+// 2. Scan 执行检查并返回结构化的漏洞记录，ctx 超时或取消时应尽快返回
+// 3. 部分目标检查失败时可以同时返回已发现的漏洞和错误
+type VulnerabilityScanner interface {
+	Name() string
+	Scan(ctx context.Context) ([]Vulnerability, error)
+}
+
+// DefaultVulnerabilityScanners 根据配置创建默认扫描插件
+// 功能说明：
+// 1. API密钥过期、JWT密钥强度检查始终启用
+// 2. handler 不为空时检查CORS策略，handler 为应用的路由引擎
+// 3. 启用OSV时检查依赖漏洞，配置了TLS目标或端口扫描主机时检查TLS配置和开放端口
+func DefaultVulnerabilityScanners(config *Config.Config, db *gorm.DB, handler http.Handler) []VulnerabilityScanner {
+	if config == nil {
+		config = &Config.Config{}
+		config.SetDefaults()
+	}
+	scan := config.Security.Scan
+
+	jwtSecret := config.JWT.Secret
+	if jwtSecret == "" {
+		jwtSecret = config.JWT.SecretKey
+	}
+	scanners := []VulnerabilityScanner{
+		NewAPIKeyExpiryScanner(db),
+		NewJWTSecretScanner(jwtSecret),
+	}
+	if handler != nil {
+		scanners = append(scanners, NewCORSScanner(handler, "/health", &config.WebSocket))
+	}
+	if scan.OSVEnabled {
+		scanners = append(scanners, NewOSVScanner(scan.OSVURL))
+	}
+	if len(scan.TLSTargets) > 0 {
+		scanners = append(scanners, NewTLSScanner(scan.TLSTargets, scan.DialTimeout, scan.CertExpiryWarning))
+	}
+	if len(scan.PortScanHosts) > 0 {
+		ports := scan.PortScanPorts
+		if len(ports) == 0 {
+			ports = Config.DefaultScanPorts
+		}
+		scanners = append(scanners, NewOpenPortScanner(scan.PortScanHosts, ports, scan.AllowedPorts, scan.DialTimeout))
+	}
+	return scanners
+}
+
+// OSVModule 依赖模块
+type OSVModule struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+}
+
+// OSVScanner 依赖漏洞扫描插件
+// 功能说明：
+// 1. 从二进制的构建信息中读取依赖模块及版本，replace 指令替换后的模块按替换结果查询
+// 2. 通过 OSV 的 /v1/querybatch 批量查询受影响的模块，再按漏洞编号获取摘要、严重程度和修复版本
+// 3. 漏洞库没有给出严重程度时按 high 处理
+type OSVScanner struct {
+	baseURL string
+	client  *OutboundHTTPClient
+	modules []OSVModule
+}
+
+// osvDependency OSV在出站HTTP客户端中的依赖名称
+const osvDependency = "osv"
+
+// osvBatchSize OSV单次批量查询的最大数量
+const osvBatchSize = 1000
+
+// osvMaxDetails 单次扫描最多获取详情的漏洞数量
+const osvMaxDetails = 200
+
+// NewOSVScanner 创建依赖漏洞扫描插件，baseURL 为空时使用 https://api.osv.dev
+func NewOSVScanner(baseURL string) *OSVScanner {
+	if baseURL == "" {
+		baseURL = "https://api.osv.dev"
+	}
+	return &OSVScanner{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  outboundClientFor(osvDependency, 30*time.Second),
+		modules: buildInfoModules(),
+	}
+}
+
+// SetModules 设置要检查的依赖模块，替代构建信息
+func (s *OSVScanner) SetModules(modules []OSVModule) {
+	s.modules = modules
+}
+
+// Name 插件名称
+func (s *OSVScanner) Name() string {
+	return ScannerDependencyCVE
+}
+
+// buildInfoModules 读取构建信息中的依赖模块，没有构建信息（如 go run 的部分场景）时返回空
+func buildInfoModules() []OSVModule {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+	var modules []OSVModule
+	for _, dep := range info.Deps {
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+		if dep.Version == "" || dep.Version == "(devel)" {
+			continue
+		}
+		modules = append(modules, OSVModule{Path: dep.Path, Version: dep.Version})
+	}
+	return modules
+}
+
+type osvQuery struct {
+	Package struct {
+		Name      string `json:"name"`
+		Ecosystem string `json:"ecosystem"`
+	} `json:"package"`
+	Version string `json:"version"`
+}
+
+type osvVuln struct {
+	ID       string   `json:"id"`
+	Summary  string   `json:"summary"`
+	Details  string   `json:"details"`
+	Aliases  []string `json:"aliases"`
+	Affected []struct {
+		Package struct {
+			Name string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Events []struct {
+				Fixed string `json:"fixed"`
+			} `json:"events"`
+		} `json:"ranges"`
+	} `json:"affected"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+}
+
+// Scan 查询依赖模块的已知漏洞
+func (s *OSVScanner) Scan(ctx context.Context) ([]Vulnerability, error) {
+	// 漏洞编号 -> 受影响的模块
+	affected := make(map[string][]OSVModule)
+	var ids []string
+	for start := 0; start < len(s.modules); start += osvBatchSize {
+		end := start + osvBatchSize
+		if end > len(s.modules) {
+			end = len(s.modules)
+		}
+		batch := s.modules[start:end]
+		queries := make([]osvQuery, len(batch))
+		for i, module := range batch {
+			queries[i].Package.Name = module.Path
+			queries[i].Package.Ecosystem = "Go"
+			queries[i].Version = strings.TrimPrefix(module.Version, "v")
+		}
+
+		var response struct {
+			Results []struct {
+				Vulns []struct {
+					ID string `json:"id"`
+				} `json:"vulns"`
+			} `json:"results"`
+		}
+		if err := s.do(ctx, http.MethodPost, "/v1/querybatch", map[string]interface{}{"queries": queries}, &response); err != nil {
+			return nil, err
+		}
+		for i, result := range response.Results {
+			if i >= len(batch) {
+				break
+			}
+			for _, vuln := range result.Vulns {
+				if _, ok := affected[vuln.ID]; !ok {
+					ids = append(ids, vuln.ID)
+				}
+				affected[vuln.ID] = append(affected[vuln.ID], batch[i])
+			}
+		}
+	}
+
+	vulnerabilities := []Vulnerability{}
+	var errs []error
+	for i, id := range ids {
+		var detail osvVuln
+		if i < osvMaxDetails {
+			if err := s.do(ctx, http.MethodGet, "/v1/vulns/"+url.PathEscape(id), nil, &detail); err != nil {
+				// 详情获取失败时仍然报告漏洞编号
+				errs = append(errs, err)
+			}
+		}
+		for _, module := range affected[id] {
+			vulnerabilities = append(vulnerabilities, osvVulnerability(id, &detail, module))
+		}
+	}
+	if len(errs) > 0 {
+		return vulnerabilities, fmt.Errorf("获取 %d 个漏洞详情失败: %w", len(errs), errs[0])
+	}
+	return vulnerabilities, nil
+}
+
+// osvVulnerability 将OSV漏洞转换为漏洞记录
+func osvVulnerability(id string, detail *osvVuln, module OSVModule) Vulnerability {
+	description := detail.Summary
+	if description == "" {
+		description = truncateUTF8(detail.Details, 500)
+	}
+	if description == "" {
+		description = "依赖存在已知漏洞 " + id
+	}
+
+	fixed := ""
+	for _, item := range detail.Affected {
+		if item.Package.Name != module.Path {
+			continue
+		}
+		for _, r := range item.Ranges {
+			for _, event := range r.Events {
+				if event.Fixed != "" {
+					fixed = event.Fixed
+				}
+			}
+		}
+	}
+	recommendation := fmt.Sprintf("关注 %s 的上游修复，评估漏洞影响并考虑替换依赖", module.Path)
+	if fixed != "" {
+		if !strings.HasPrefix(fixed, "v") {
+			fixed = "v" + fixed
+		}
+		recommendation = fmt.Sprintf("升级 %s 到 %s 或更高版本", module.Path, fixed)
+	}
+
+	references := append([]string{"https://osv.dev/vulnerability/" + id}, detail.Aliases...)
+	return Vulnerability{
+		ID:             id,
+		Type:           "dependency_vulnerability",
+		Severity:       osvSeverity(detail.DatabaseSpecific.Severity),
+		Description:    description,
+		Location:       module.Path + "@" + module.Version,
+		Recommendation: recommendation,
+		References:     references,
+	}
+}
+
+// osvSeverity 转换漏洞库的严重程度，未知时按 high 处理
+func osvSeverity(severity string) string {
+	switch strings.ToUpper(severity) {
+	case "CRITICAL":
+		return "critical"
+	case "MODERATE", "MEDIUM":
+		return "medium"
+	case "LOW":
+		return "low"
+	default:
+		return "high"
+	}
+}
+
+// do 调用OSV API
+func (s *OSVScanner) do(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := s.client.Do(osvDependency, req)
+	if err != nil {
+		return fmt.Errorf("请求OSV失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("请求OSV %s 返回状态码 %d", path, resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 16<<20)).Decode(out); err != nil {
+		return fmt.Errorf("解析OSV响应失败: %w", err)
+	}
+	return nil
+}
+
+// TLSScanner TLS配置检查插件
+// 功能说明：
+// 1. 连接每个 host:port 目标完成TLS握手，检查证书是否受信任、与主机名匹配
+// 2. 证书已过期为 critical，在告警时间内过期为 medium
+// 3. 协商版本低于 TLS 1.2 或仍接受 TLS 1.0/1.1 握手时报告
+type TLSScanner struct {
+	targets       []string
+	dialTimeout   time.Duration
+	expiryWarning time.Duration
+	rootCAs       *x509.CertPool
+	now           func() time.Time
+}
+
+// NewTLSScanner 创建TLS配置检查插件
+func NewTLSScanner(targets []string, dialTimeout, expiryWarning time.Duration) *TLSScanner {
+	if dialTimeout <= 0 {
+		dialTimeout = 3 * time.Second
+	}
+	return &TLSScanner{
+		targets:       targets,
+		dialTimeout:   dialTimeout,
+		expiryWarning: expiryWarning,
+		now:           time.Now,
+	}
+}
+
+// SetRootCAs 设置验证证书使用的根证书，为空时使用系统根证书
+func (s *TLSScanner) SetRootCAs(pool *x509.CertPool) {
+	s.rootCAs = pool
+}
+
+// Name 插件名称
+func (s *TLSScanner) Name() string {
+	return ScannerTLSConfig
+}
+
+// Scan 检查所有TLS目标，无法连接的目标作为错误返回
+func (s *TLSScanner) Scan(ctx context.Context) ([]Vulnerability, error) {
+	vulnerabilities := []Vulnerability{}
+	var errs []error
+	for _, target := range s.targets {
+		found, err := s.scanTarget(ctx, target)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", target, err))
+			continue
+		}
+		vulnerabilities = append(vulnerabilities, found...)
+	}
+	return vulnerabilities, errors.Join(errs...)
+}
+
+// handshake 使用指定的版本范围完成TLS握手，不验证证书
+func (s *TLSScanner) handshake(ctx context.Context, target, host string, minVersion, maxVersion uint16) (tls.ConnectionState, error) {
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: s.dialTimeout},
+		Config: &tls.Config{
+			ServerName:         host,
+			InsecureSkipVerify: true, // 证书由 scanTarget 单独验证，以便区分不同的问题
+			MinVersion:         minVersion,
+			MaxVersion:         maxVersion,
+		},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", target)
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	defer conn.Close()
+	return conn.(*tls.Conn).ConnectionState(), nil
+}
+
+func (s *TLSScanner) scanTarget(ctx context.Context, target string) ([]Vulnerability, error) {
+	host, _, err := net.SplitHostPort(target)
+	if err != nil {
+		return nil, err
+	}
+	state, err := s.handshake(ctx, target, host, tls.VersionTLS10, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(state.PeerCertificates) == 0 {
+		return nil, fmt.Errorf("服务器没有返回证书")
+	}
+
+	var vulnerabilities []Vulnerability
+	add := func(vulnType, severity, description, recommendation string) {
+		vulnerabilities = append(vulnerabilities, Vulnerability{
+			Type:           vulnType,
+			Severity:       severity,
+			Description:    description,
+			Location:       target,
+			Recommendation: recommendation,
+		})
+	}
+
+	leaf := state.PeerCertificates[0]
+	now := s.now()
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, verifyErr := leaf.Verify(x509.VerifyOptions{
+		DNSName:       host,
+		Roots:         s.rootCAs,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+	})
+	var invalidErr x509.CertificateInvalidError
+	expired := errors.As(verifyErr, &invalidErr) && invalidErr.Reason == x509.Expired
+	switch {
+	case now.After(leaf.NotAfter):
+		add("tls_certificate_expired", "critical",
+			fmt.Sprintf("证书已于 %s 过期", leaf.NotAfter.Format(time.RFC3339)), "立即更换证书")
+	case s.expiryWarning > 0 && leaf.NotAfter.Sub(now) < s.expiryWarning:
+		add("tls_certificate_expiring", "medium",
+			fmt.Sprintf("证书将于 %s 过期", leaf.NotAfter.Format(time.RFC3339)), "在证书过期前续期，建议启用自动续期")
+	}
+	if verifyErr != nil && !expired {
+		add("tls_certificate_untrusted", "high",
+			fmt.Sprintf("证书验证失败: %v", verifyErr), "使用受信任CA签发且包含该主机名的证书，并配置完整的证书链")
+	}
+
+	if state.Version < tls.VersionTLS12 {
+		add("tls_weak_protocol", "high",
+			fmt.Sprintf("协商的最高协议版本为 %s", tls.VersionName(state.Version)), "启用 TLS 1.2 和 TLS 1.3")
+	} else if legacy, err := s.handshake(ctx, target, host, tls.VersionTLS10, tls.VersionTLS11); err == nil {
+		add("tls_legacy_protocol", "medium",
+			fmt.Sprintf("服务器仍接受 %s 握手", tls.VersionName(legacy.Version)), "禁用 TLS 1.0 和 TLS 1.1，最低版本设置为 TLS 1.2")
+	}
+	return vulnerabilities, nil
+}
+
+// JWTSecretScanner JWT密钥强度检查插件
+// 功能说明：
+// 1. 密钥为空、使用示例配置或常见默认值时为 critical
+// 2. 长度不足32个字符时为 high
+// 3. 字符种类过少（熵过低）时为 medium
+// 4. 漏洞记录中不包含密钥内容
+type JWTSecretScanner struct {
+	secret string
+}
+
+// jwtSecretMinLength JWT密钥的最小长度
+const jwtSecretMinLength = 32
+
+// jwtSecretMinEntropy JWT密钥每个字符的最小香农熵（比特）
+const jwtSecretMinEntropy = 3.0
+
+// knownJWTSecrets 示例配置和常见的默认密钥
+var knownJWTSecrets = []string{
+	"your-super-secret-jwt-key-change-in-production-must-be-at-least-32-characters-long",
+	"your_super_secret_jwt_key_at_least_32_characters_long_for_production",
+	"secret", "changeme", "change-me", "jwt-secret", "jwt_secret", "your-secret-key", "your_secret_key",
+	"your-256-bit-secret", "mysecret", "supersecret", "password", "123456",
+}
+
+// NewJWTSecretScanner 创建JWT密钥强度检查插件
+func NewJWTSecretScanner(secret string) *JWTSecretScanner {
+	return &JWTSecretScanner{secret: secret}
+}
+
+// Name 插件名称
+func (s *JWTSecretScanner) Name() string {
+	return ScannerJWTSecret
+}
+
+// Scan 检查JWT密钥强度
+func (s *JWTSecretScanner) Scan(ctx context.Context) ([]Vulnerability, error) {
+	vuln := Vulnerability{
+		Type:           "weak_jwt_secret",
+		Location:       "JWT_SECRET",
+		Recommendation: "使用 openssl rand -base64 48 等方式生成随机密钥，更换后已签发的令牌将失效",
+	}
+	secret := strings.TrimSpace(s.secret)
+	lower := strings.ToLower(secret)
+	known := false
+	for _, value := range knownJWTSecrets {
+		if lower == value {
+			known = true
+			break
+		}
+	}
+
+	switch {
+	case secret == "":
+		vuln.Severity, vuln.Description = "critical", "JWT密钥未配置"
+	case known || strings.Contains(lower, "change-in-production") || strings.Contains(lower, "your-super-secret") || strings.Contains(lower, "your_super_secret"):
+		vuln.Severity, vuln.Description = "critical", "JWT密钥使用了示例配置或常见默认值，攻击者可以伪造任意用户的令牌"
+	case len(secret) < jwtSecretMinLength:
+		vuln.Severity, vuln.Description = "high", fmt.Sprintf("JWT密钥长度为 %d 个字符，少于 %d 个字符", len(secret), jwtSecretMinLength)
+	case shannonEntropy(secret) < jwtSecretMinEntropy:
+		vuln.Severity, vuln.Description = "medium", "JWT密钥字符种类过少，容易被暴力破解"
+	default:
+		return []Vulnerability{}, nil
+	}
+	return []Vulnerability{vuln}, nil
+}
+
+// shannonEntropy 计算字符串每个字符的香农熵（比特）
+func shannonEntropy(value string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range value {
+		counts[r]++
+		total++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// CORSScanner CORS策略检查插件
+// 功能说明：
+// 1. 使用不可信的 Origin 向应用发送普通请求和预检请求，检查实际返回的CORS响应头
+// 2. 反射任意来源并允许携带凭证为 high，允许任意来源为 medium
+// 3. WebSocket 未启用来源检查或允许任意来源时为 medium
+type CORSScanner struct {
+	handler   http.Handler
+	path      string
+	websocket *Config.WebSocketConfig
+}
+
+// corsProbeOrigin 检查CORS时使用的不可信来源
+const corsProbeOrigin = "https://cors-probe.invalid"
+
+// NewCORSScanner 创建CORS策略检查插件，path 为探测的路径，websocket 为空时不检查WebSocket配置
+func NewCORSScanner(handler http.Handler, path string, websocket *Config.WebSocketConfig) *CORSScanner {
+	return &CORSScanner{handler: handler, path: path, websocket: websocket}
+}
+
+// Name 插件名称
+func (s *CORSScanner) Name() string {
+	return ScannerCORSPolicy
+}
+
+// Scan 检查CORS策略
+func (s *CORSScanner) Scan(ctx context.Context) ([]Vulnerability, error) {
+	vulnerabilities := []Vulnerability{}
+	for _, method := range []string{http.MethodGet, http.MethodOptions} {
+		req := httptest.NewRequest(method, s.path, nil).WithContext(ctx)
+		req.Header.Set("Origin", corsProbeOrigin)
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			req.Header.Set("Access-Control-Request-Headers", "Authorization")
+		}
+		w := httptest.NewRecorder()
+		s.handler.ServeHTTP(w, req)
+
+		allowOrigin := w.Header().Get("Access-Control-Allow-Origin")
+		credentials := strings.EqualFold(w.Header().Get("Access-Control-Allow-Credentials"), "true")
+		vuln := Vulnerability{
+			Type:           "permissive_cors",
+			Location:       method + " " + s.path,
+			Recommendation: "只允许受信任的前端域名跨域访问，需要携带凭证时不能使用通配符或反射请求的 Origin",
+		}
+		switch {
+		case allowOrigin == corsProbeOrigin && credentials:
+			vuln.Severity, vuln.Description = "high", "CORS 反射任意请求来源并允许携带凭证，任意网站都可以读取用户的认证响应"
+		case allowOrigin == corsProbeOrigin || allowOrigin == "null":
+			vuln.Severity, vuln.Description = "medium", "CORS 允许不受信任的请求来源 "+allowOrigin
+		case allowOrigin == "*":
+			vuln.Severity, vuln.Description = "medium", "CORS 允许任意来源（*）跨域访问"
+			if credentials {
+				vuln.Description += "，同时设置了 Access-Control-Allow-Credentials: true"
+			}
+		default:
+			continue
+		}
+		vulnerabilities = append(vulnerabilities, vuln)
+	}
+
+	if s.websocket != nil {
+		vuln := Vulnerability{
+			Type:           "permissive_websocket_origin",
+			Severity:       "medium",
+			Location:       "websocket",
+			Recommendation: "启用 WebSocket 来源检查，并只允许受信任的前端域名",
+		}
+		if !s.websocket.EnableOriginCheck {
+			vuln.Description = "WebSocket 未启用来源检查，任意网站都可以建立跨站 WebSocket 连接"
+			vulnerabilities = append(vulnerabilities, vuln)
+		} else {
+			for _, origin := range s.websocket.AllowedOrigins {
+				if strings.TrimSpace(origin) == "*" {
+					vuln.Description = "WebSocket 允许任意来源（*）"
+					vulnerabilities = append(vulnerabilities, vuln)
+					break
+				}
+			}
+		}
+	}
+	return vulnerabilities, nil
+}
+
+// OpenPortScanner 开放端口扫描插件
+// 功能说明：
+// 1. 对每个主机并发探测配置的TCP端口，允许开放的端口不报告
+// 2. 数据库、缓存、远程管理等高风险服务的端口为 high，其他端口为 medium
+type OpenPortScanner struct {
+	hosts       []string
+	ports       []int
+	allowed     map[int]bool
+	dialTimeout time.Duration
+}
+
+// portScanConcurrency 端口扫描的并发数
+const portScanConcurrency = 16
+
+// riskyPorts 高风险服务端口
+var riskyPorts = map[int]string{
+	23:    "Telnet",
+	445:   "SMB",
+	2375:  "Docker API",
+	2379:  "etcd",
+	3306:  "MySQL",
+	3389:  "RDP",
+	5432:  "PostgreSQL",
+	6379:  "Redis",
+	9200:  "Elasticsearch",
+	11211: "Memcached",
+	27017: "MongoDB",
+}
+
+// portServices 其他常见服务端口
+var portServices = map[int]string{
+	21: "FTP",
+	22: "SSH",
+	25: "SMTP",
+}
+
+// NewOpenPortScanner 创建开放端口扫描插件
+func NewOpenPortScanner(hosts []string, ports, allowedPorts []int, dialTimeout time.Duration) *OpenPortScanner {
+	if dialTimeout <= 0 {
+		dialTimeout = 3 * time.Second
+	}
+	allowed := make(map[int]bool, len(allowedPorts))
+	for _, port := range allowedPorts {
+		allowed[port] = true
+	}
+	return &OpenPortScanner{hosts: hosts, ports: ports, allowed: allowed, dialTimeout: dialTimeout}
+}
+
+// Name 插件名称
+func (s *OpenPortScanner) Name() string {
+	return ScannerOpenPorts
+}
+
+// Scan 探测开放端口，结果按主机和端口排序
+func (s *OpenPortScanner) Scan(ctx context.Context) ([]Vulnerability, error) {
+	type probe struct {
+		host string
+		port int
+	}
+	probes := make(chan probe)
+	var (
+		mutex sync.Mutex
+		open  []probe
+		wg    sync.WaitGroup
+	)
+	dialer := &net.Dialer{Timeout: s.dialTimeout}
+	for i := 0; i < portScanConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range probes {
+				conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(p.host, strconv.Itoa(p.port)))
+				if err != nil {
+					continue
+				}
+				conn.Close()
+				mutex.Lock()
+				open = append(open, p)
+				mutex.Unlock()
+			}
+		}()
+	}
+send:
+	for _, host := range s.hosts {
+		for _, port := range s.ports {
+			if s.allowed[port] {
+				continue
+			}
+			select {
+			case probes <- probe{host: host, port: port}:
+			case <-ctx.Done():
+				break send
+			}
+		}
+	}
+	close(probes)
+	wg.Wait()
+
+	sort.Slice(open, func(i, j int) bool {
+		if open[i].host != open[j].host {
+			return open[i].host < open[j].host
+		}
+		return open[i].port < open[j].port
+	})
+	vulnerabilities := make([]Vulnerability, 0, len(open))
+	for _, p := range open {
+		severity := "medium"
+		service, risky := riskyPorts[p.port]
+		if risky {
+			severity = "high"
+		} else if service = portServices[p.port]; service == "" {
+			service = "未知服务"
+		}
+		vulnerabilities = append(vulnerabilities, Vulnerability{
+			Type:           "open_port",
+			Severity:       severity,
+			Description:    fmt.Sprintf("端口 %d（%s）可以从扫描位置访问", p.port, service),
+			Location:       net.JoinHostPort(p.host, strconv.Itoa(p.port)),
+			Recommendation: "使用防火墙或安全组限制该端口的访问来源；确需开放时加入 SECURITY_SCAN_ALLOWED_PORTS",
+		})
+	}
+	return vulnerabilities, ctx.Err()
+}
+
+// APIKeyExpiryScanner API密钥过期检查插件，报告已过期但仍处于启用状态的API密钥
+type APIKeyExpiryScanner struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyExpiryScanner 创建API密钥过期检查插件
+func NewAPIKeyExpiryScanner(db *gorm.DB) *APIKeyExpiryScanner {
+	return &APIKeyExpiryScanner{db: db}
+}
+
+// Name 插件名称
+func (s *APIKeyExpiryScanner) Name() string {
+	return ScannerAPIKeyExpiry
+}
+
+// Scan 检查过期的API密钥
+func (s *APIKeyExpiryScanner) Scan(ctx context.Context) ([]Vulnerability, error) {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&Models.ApiKey{}).
+		Where("status = ? AND expires_at IS NOT NULL AND expires_at < ?", 1, time.Now()).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return []Vulnerability{}, nil
+	}
+	return []Vulnerability{{
+		Type:           "api_key_security",
+		Severity:       "medium",
+		Description:    fmt.Sprintf("发现 %d 个已过期但仍处于启用状态的API密钥", count),
+		Location:       "api_keys",
+		Recommendation: "禁用或删除过期的API密钥",
+	}}, nil
+}
@@ -0,0 +1,210 @@
+package Services
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// wechatWorkDependency 企业微信在出站HTTP客户端中的依赖名称
+const wechatWorkDependency = "wechat_work"
+
+// 企业微信群机器人消息长度上限（字节）
+const (
+	wechatWorkMaxMarkdownBytes = 4096
+	wechatWorkMaxTextBytes     = 2048
+)
+
+// wechatWorkErrFrequencyLimit 企业微信接口调用超过频率限制的错误码
+const wechatWorkErrFrequencyLimit = 45009
+
+// WeChatWorkNotificationChannel 企业微信群机器人通知通道
+// 功能说明：
+// 1. 将告警发送到 NotificationConfig.WeChatWork 配置的群机器人Webhook
+// 2. markdown 消息按告警级别着色，以 <@userid> 提及成员；text 消息使用 mentioned_list 和 mentioned_mobile_list
+// 3. 每个机器人每分钟最多20条消息，超过限制或服务商返回45009时暂停发送
+type WeChatWorkNotificationChannel struct {
+	webhookURL       string
+	msgType          string
+	mentionedList    []string
+	mentionedMobiles []string
+	enabled          bool
+	client           *OutboundHTTPClient
+	limiter          *notificationRateLimiter
+}
+
+// NewWeChatWorkNotificationChannel 创建企业微信通知通道
+//
+// client 为 nil 时使用全局出站HTTP客户端。
+func NewWeChatWorkNotificationChannel(config *Config.MonitoringConfig, client *OutboundHTTPClient) *WeChatWorkNotificationChannel {
+	if client == nil {
+		client = GetOutboundHTTPClient()
+	}
+
+	// 限流由通道自己处理，出站客户端不再对429重试
+	policy := client.DefaultPolicy()
+	policy.MaxRetries = 0
+	client.SetPolicy(wechatWorkDependency, policy)
+
+	wechatWork := config.NotificationConfig.WeChatWork
+	msgType := wechatWork.MsgType
+	if msgType == "" {
+		msgType = "markdown"
+	}
+
+	return &WeChatWorkNotificationChannel{
+		webhookURL:       wechatWork.WebhookURL,
+		msgType:          msgType,
+		mentionedList:    splitNotificationList(wechatWork.MentionedList),
+		mentionedMobiles: splitNotificationList(wechatWork.MentionedMobiles),
+		enabled:          wechatWork.Enabled,
+		client:           client,
+		limiter:          newNotificationRateLimiter(wechatWork.RateLimit, time.Minute),
+	}
+}
+
+// wechatWorkSeverityColor 告警级别对应的markdown字体颜色
+func wechatWorkSeverityColor(severity string) string {
+	switch strings.ToLower(severity) {
+	case "critical", "error", "high":
+		return "warning"
+	case "warning", "medium":
+		return "comment"
+	default:
+		return "info"
+	}
+}
+
+// truncateUTF8 按字节截断文本，不截断多字节字符
+func truncateUTF8(text string, maxBytes int) string {
+	if len(text) <= maxBytes {
+		return text
+	}
+	cut := maxBytes - len("...")
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "..."
+}
+
+// BuildWeChatWorkPayload 将告警转换为群机器人消息
+func (wnc *WeChatWorkNotificationChannel) BuildWeChatWorkPayload(alert MonitoringAlert) map[string]interface{} {
+	var details []string
+	if alert.Source != "" {
+		details = append(details, "来源: "+alert.Source)
+	}
+	if !alert.Timestamp.IsZero() {
+		details = append(details, "时间: "+alert.Timestamp.Format("2006-01-02 15:04:05"))
+	}
+
+	if wnc.msgType == "text" {
+		content := fmt.Sprintf("[%s] %s\n%s", strings.ToUpper(alert.Severity), alert.Title, alert.Message)
+		if len(details) > 0 {
+			content += "\n" + strings.Join(details, "\n")
+		}
+		text := map[string]interface{}{"content": truncateUTF8(content, wechatWorkMaxTextBytes)}
+		if len(wnc.mentionedList) > 0 {
+			text["mentioned_list"] = wnc.mentionedList
+		}
+		if len(wnc.mentionedMobiles) > 0 {
+			text["mentioned_mobile_list"] = wnc.mentionedMobiles
+		}
+		return map[string]interface{}{"msgtype": "text", "text": text}
+	}
+
+	// markdown 消息不支持 @all 和按手机号提及
+	var mentions []string
+	for _, userID := range wnc.mentionedList {
+		if userID != "@all" {
+			mentions = append(mentions, "<@"+userID+">")
+		}
+	}
+	suffix := ""
+	if len(details) > 0 {
+		suffix += "\n> " + strings.Join(details, "\n> ")
+	}
+	if len(mentions) > 0 {
+		suffix += "\n" + strings.Join(mentions, " ")
+	}
+	header := fmt.Sprintf("### <font color=\"%s\">[%s]</font> %s\n",
+		wechatWorkSeverityColor(alert.Severity), strings.ToUpper(alert.Severity), alert.Title)
+	// 截断告警正文，保留标题、来源和提及
+	body := truncateUTF8(alert.Message, wechatWorkMaxMarkdownBytes-len(header)-len(suffix))
+	return map[string]interface{}{
+		"msgtype":  "markdown",
+		"markdown": map[string]interface{}{"content": header + body + suffix},
+	}
+}
+
+// SendAlert 发送告警
+func (wnc *WeChatWorkNotificationChannel) SendAlert(alert MonitoringAlert) error {
+	if !wnc.enabled {
+		return fmt.Errorf("企业微信通知通道已禁用")
+	}
+	if wnc.webhookURL == "" {
+		return fmt.Errorf("企业微信通知通道未配置Webhook地址")
+	}
+	if ok, wait := wnc.limiter.Allow(wechatWorkDependency); !ok {
+		return &NotificationRateLimitError{Channel: "企业微信", Target: "群机器人", RetryAfter: wait}
+	}
+
+	body, err := json.Marshal(wnc.BuildWeChatWorkPayload(alert))
+	if err != nil {
+		return fmt.Errorf("序列化企业微信消息失败: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, wnc.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建企业微信请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := wnc.client.Do(wechatWorkDependency, req)
+	if err != nil {
+		return fmt.Errorf("发送企业微信告警失败: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("企业微信返回错误状态: %s", resp.Status)
+	}
+	var result struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return fmt.Errorf("解析企业微信响应失败: %v", err)
+	}
+	switch result.ErrCode {
+	case 0:
+		return nil
+	case wechatWorkErrFrequencyLimit:
+		wnc.limiter.Block(wechatWorkDependency, time.Minute)
+		return &NotificationRateLimitError{Channel: "企业微信", Target: "群机器人", RetryAfter: time.Minute}
+	default:
+		return fmt.Errorf("企业微信返回错误: %d %s", result.ErrCode, result.ErrMsg)
+	}
+}
+
+// GetName 获取通道名称
+func (wnc *WeChatWorkNotificationChannel) GetName() string {
+	return wechatWorkDependency
+}
+
+// IsEnabled 检查是否启用
+func (wnc *WeChatWorkNotificationChannel) IsEnabled() bool {
+	return wnc.enabled && wnc.webhookURL != ""
+}
+
+// SetEnabled 设置启用状态
+func (wnc *WeChatWorkNotificationChannel) SetEnabled(enabled bool) {
+	wnc.enabled = enabled
+}
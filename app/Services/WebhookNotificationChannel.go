@@ -0,0 +1,122 @@
+package Services
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// WebhookNotificationChannel Webhook通知通道
+// 功能说明：
+// 1. 以JSON格式将告警发送到 NotificationConfig.Webhook 配置的地址
+// 2. 通过出站HTTP客户端发送，超时和重试次数使用Webhook配置，统计和熔断按 "webhook" 依赖记录
+type WebhookNotificationChannel struct {
+	url     string
+	method  string
+	headers map[string]string
+	enabled bool
+	client  *OutboundHTTPClient
+}
+
+// webhookDependency Webhook在出站HTTP客户端中的依赖名称
+const webhookDependency = "webhook"
+
+// NewWebhookNotificationChannel 创建Webhook通知通道
+//
+// client 为 nil 时使用全局出站HTTP客户端。
+func NewWebhookNotificationChannel(config *Config.MonitoringConfig, client *OutboundHTTPClient) *WebhookNotificationChannel {
+	if client == nil {
+		client = GetOutboundHTTPClient()
+	}
+
+	webhook := config.NotificationConfig.Webhook
+	policy := client.DefaultPolicy()
+	if webhook.Timeout > 0 {
+		policy.Timeout = webhook.Timeout
+	}
+	if webhook.RetryCount > 0 {
+		policy.MaxRetries = webhook.RetryCount
+	}
+	client.SetPolicy(webhookDependency, policy)
+
+	method := strings.ToUpper(webhook.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	return &WebhookNotificationChannel{
+		url:     webhook.URL,
+		method:  method,
+		headers: parseWebhookHeaders(webhook.Headers),
+		enabled: webhook.Enabled,
+		client:  client,
+	}
+}
+
+// parseWebhookHeaders 解析请求头配置，格式为 "Key:Value,Key:Value"
+func parseWebhookHeaders(raw string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		key, value, ok := strings.Cut(pair, ":")
+		if key = strings.TrimSpace(key); ok && key != "" {
+			headers[key] = strings.TrimSpace(value)
+		}
+	}
+	return headers
+}
+
+// SendAlert 发送告警
+func (wnc *WebhookNotificationChannel) SendAlert(alert MonitoringAlert) error {
+	if !wnc.enabled {
+		return fmt.Errorf("Webhook通知通道已禁用")
+	}
+
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("序列化告警失败: %v", err)
+	}
+
+	// 出站客户端从上下文中读取请求ID和关联ID设置请求头
+	ctx := Utils.WithRequestIDs(context.Background(), alert.RequestID, alert.CorrelationID)
+	req, err := http.NewRequestWithContext(ctx, wnc.method, wnc.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建Webhook请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range wnc.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := wnc.client.Do(webhookDependency, req)
+	if err != nil {
+		return fmt.Errorf("发送Webhook告警失败: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("Webhook返回错误状态: %s", resp.Status)
+	}
+	return nil
+}
+
+// GetName 获取通道名称
+func (wnc *WebhookNotificationChannel) GetName() string {
+	return webhookDependency
+}
+
+// IsEnabled 检查是否启用
+func (wnc *WebhookNotificationChannel) IsEnabled() bool {
+	return wnc.enabled
+}
+
+// SetEnabled 设置启用状态
+func (wnc *WebhookNotificationChannel) SetEnabled(enabled bool) {
+	wnc.enabled = enabled
+}
@@ -0,0 +1,835 @@
+package Services
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Utils"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrWebhookSubscriptionNotFound Webhook订阅不存在
+	ErrWebhookSubscriptionNotFound = errors.New("Webhook订阅不存在")
+	// ErrInvalidWebhookSubscription Webhook订阅参数无效
+	ErrInvalidWebhookSubscription = errors.New("Webhook订阅参数无效")
+	// ErrWebhookDeliveryNotFound 投递记录不存在
+	ErrWebhookDeliveryNotFound = errors.New("投递记录不存在")
+	// ErrWebhookDeliveryNotRetryable 只有发送失败的投递记录可以手动重试
+	ErrWebhookDeliveryNotRetryable = errors.New("只能重试发送失败的投递记录")
+	// ErrWebhookPrivateAddress 接收地址指向内网或本机
+	ErrWebhookPrivateAddress = errors.New("不允许投递到内网或本机地址")
+)
+
+// Webhook请求头
+const (
+	WebhookHeaderEvent     = "X-Webhook-Event"     // 事件类型
+	WebhookHeaderDelivery  = "X-Webhook-Delivery"  // 投递ID，重试时不变
+	WebhookHeaderTimestamp = "X-Webhook-Timestamp" // 发送时间（Unix秒），参与签名
+	WebhookHeaderSignature = "X-Webhook-Signature" // sha256=HMAC-SHA256(密钥, 时间戳 + "." + 请求体)
+)
+
+// WebhookEventPing 测试事件，只发送到指定的订阅
+const WebhookEventPing = "webhook.ping"
+
+// webhookResponseLimit 投递记录中保存的响应内容长度
+const webhookResponseLimit = 1024
+
+// WebhookEventType 可订阅的事件类型
+type WebhookEventType struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+// WebhookEventTypes 可订阅的事件类型列表
+var WebhookEventTypes = []WebhookEventType{
+	{Type: EventUserCreated, Description: "创建用户，包括注册、管理员创建和批量导入"},
+	{Type: EventUserRegistered, Description: "用户自行注册"},
+	{Type: EventAlertFired, Description: "告警触发"},
+	{Type: EventAlertResolved, Description: "告警恢复"},
+	{Type: EventSecurityEventHigh, Description: "记录了high或critical级别的安全事件"},
+	{Type: EventBackupCompleted, Description: "备份完成"},
+	{Type: EventBackupFailed, Description: "备份失败"},
+	{Type: EventBackupDrillFailed, Description: "备份恢复演练失败"},
+}
+
+// WebhookSubscriptionInput 创建和更新Webhook订阅的参数
+type WebhookSubscriptionInput struct {
+	Name        string                 `json:"name" binding:"required,max=100"`
+	Description string                 `json:"description" binding:"max=500"`
+	URL         string                 `json:"url" binding:"required,max=500"`
+	EventTypes  []string               `json:"event_types" binding:"required,min=1"` // 事件类型，支持 * 和 alert.* 前缀通配
+	Filter      map[string]interface{} `json:"filter"`                               // 事件内容筛选条件，值为期望的值或可选值数组
+	Secret      string                 `json:"secret"`                               // 签名密钥，创建时为空则自动生成，更新时为空表示不修改
+}
+
+// WebhookPayload 发送给订阅方的请求体
+type WebhookPayload struct {
+	EventID       string          `json:"event_id"`
+	EventType     string          `json:"event_type"`
+	AggregateType string          `json:"aggregate_type,omitempty"`
+	AggregateID   string          `json:"aggregate_id,omitempty"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// WebhookService Webhook订阅服务
+// 功能说明：
+// 1. 作为事件总线的处理器接收领域事件，按订阅的事件类型和筛选条件为每个订阅生成投递记录
+// 2. 投递记录由后台协程发送，请求体带HMAC-SHA256签名，失败按指数退避重试，服务重启后继续发送
+// 3. 暂停的订阅照常生成投递记录但不发送，恢复后按顺序补发
+// 4. 投递记录保存最近一次的响应状态码、响应内容和耗时，发送失败的记录可手动重试
+type WebhookService struct {
+	db     *gorm.DB
+	config Config.WebhookConfig
+	client *OutboundHTTPClient
+
+	mu      sync.Mutex
+	started bool
+	queue   chan uint
+	stopCh  chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewWebhookService 创建Webhook订阅服务
+func NewWebhookService(db *gorm.DB, config *Config.WebhookConfig) *WebhookService {
+	cfg := Config.WebhookConfig{}
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1000
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 8
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 30 * time.Second
+	}
+	if cfg.MaxBackoff < cfg.RetryBackoff {
+		cfg.MaxBackoff = max(cfg.RetryBackoff, 6*time.Hour)
+	}
+
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivateNetworks {
+		// 在连接时检查解析后的地址，域名解析到内网地址时同样拒绝
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip != nil && isPrivateWebhookIP(ip) {
+				return fmt.Errorf("%w: %s", ErrWebhookPrivateAddress, host)
+			}
+			return nil
+		}
+	}
+
+	return &WebhookService{
+		db:     db,
+		config: cfg,
+		client: GetOutboundHTTPClient().WithHTTPClient(&http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: &http.Transport{DialContext: dialer.DialContext, MaxIdleConnsPerHost: 2},
+			// 不跟随重定向，避免绕过接收地址检查
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}),
+	}
+}
+
+// webhookDependency 接收地址在出站HTTP客户端中的依赖名称，按主机分别统计和熔断
+//
+// 重试由投递记录的退避计划负责，出站客户端不再重试。
+func (s *WebhookService) webhookDependency(rawURL string) string {
+	dependency := "webhook:" + outboundHost(rawURL)
+	policy := s.client.DefaultPolicy()
+	policy.MaxRetries = 0
+	policy.Timeout = s.config.Timeout
+	s.client.SetPolicy(dependency, policy)
+	return dependency
+}
+
+// isPrivateWebhookIP 是否为内网、本机或保留地址
+func isPrivateWebhookIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast()
+}
+
+// Start 启动发送协程，并恢复上次未发送完成的投递
+func (s *WebhookService) Start() {
+	s.mu.Lock()
+	if s.started {
+		s.mu.Unlock()
+		return
+	}
+	s.queue = make(chan uint, s.config.QueueSize)
+	s.stopCh = make(chan struct{})
+	s.started = true
+	s.mu.Unlock()
+
+	for i := 0; i < s.config.Workers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+
+	// 上次退出时正在发送的投递无法确认结果，按待重试处理
+	s.db.Model(&Models.WebhookDelivery{}).Where("status = ?", Models.WebhookDeliveryDelivering).
+		Update("status", Models.WebhookDeliveryRetrying)
+
+	s.wg.Add(1)
+	go s.sweeper()
+}
+
+// Stop 停止发送协程，等待正在发送的请求完成
+func (s *WebhookService) Stop() {
+	s.mu.Lock()
+	if !s.started {
+		s.mu.Unlock()
+		return
+	}
+	s.started = false
+	close(s.stopCh)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *WebhookService) worker() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case id := <-s.queue:
+			if err := s.Deliver(context.Background(), id); err != nil {
+				log.Printf("发送Webhook失败: id=%d, error=%v", id, err)
+			}
+		}
+	}
+}
+
+// sweeper 定期把到期的投递放回队列
+func (s *WebhookService) sweeper() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		s.enqueueDue(0)
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// enqueueDue 把到期的投递放入队列，subscriptionID 不为0时只处理该订阅
+func (s *WebhookService) enqueueDue(subscriptionID uint) {
+	s.mu.Lock()
+	started := s.started
+	s.mu.Unlock()
+	if !started {
+		return
+	}
+
+	active := s.db.Model(&Models.WebhookSubscription{}).Select("id").Where("status = ?", Models.WebhookSubscriptionActive)
+	query := s.db.Model(&Models.WebhookDelivery{}).
+		Where("status IN ?", []string{Models.WebhookDeliveryPending, Models.WebhookDeliveryRetrying}).
+		Where("next_attempt_at IS NULL OR next_attempt_at <= ?", time.Now()).
+		Where("subscription_id IN (?)", active)
+	if subscriptionID != 0 {
+		query = query.Where("subscription_id = ?", subscriptionID)
+	}
+	var ids []uint
+	if err := query.Order("id").Limit(s.config.QueueSize).Pluck("id", &ids).Error; err != nil {
+		log.Printf("查询待发送Webhook失败: %v", err)
+		return
+	}
+	for _, id := range ids {
+		s.enqueue(id)
+	}
+}
+
+// enqueue 放入发送队列，服务未启动或队列已满时投递记录保留在数据库中，由 sweeper 稍后处理
+func (s *WebhookService) enqueue(id uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.started {
+		return
+	}
+	select {
+	case s.queue <- id:
+	default:
+		log.Printf("Webhook发送队列已满，稍后重试: id=%d", id)
+	}
+}
+
+// HandleEvent 事件总线处理器：为订阅了该事件的订阅生成投递记录
+//
+// 事件总线的投递语义为至少一次，同一事件重复到达时按 (订阅ID, 事件ID) 唯一索引忽略。
+func (s *WebhookService) HandleEvent(ctx context.Context, event DomainEvent) error {
+	if event.Type == WebhookEventPing {
+		return nil
+	}
+
+	var subscriptions []Models.WebhookSubscription
+	if err := s.db.WithContext(ctx).Find(&subscriptions).Error; err != nil {
+		return err
+	}
+
+	for _, subscription := range subscriptions {
+		if !subscription.Matches(event.Type) || !matchWebhookFilter(subscription.Filter, event.Payload) {
+			continue
+		}
+		delivery, err := newWebhookDelivery(subscription.ID, event)
+		if err != nil {
+			return err
+		}
+		result := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(delivery)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 1 && subscription.Status == Models.WebhookSubscriptionActive {
+			s.enqueue(delivery.ID)
+		}
+	}
+	return nil
+}
+
+// newWebhookDelivery 生成待发送的投递记录
+func newWebhookDelivery(subscriptionID uint, event DomainEvent) (*Models.WebhookDelivery, error) {
+	data := event.Payload
+	if len(data) == 0 {
+		data = json.RawMessage("null")
+	}
+	body, err := json.Marshal(WebhookPayload{
+		EventID:       event.ID,
+		EventType:     event.Type,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		OccurredAt:    event.OccurredAt,
+		Data:          data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Models.WebhookDelivery{
+		DeliveryID:     uuid.NewString(),
+		SubscriptionID: subscriptionID,
+		EventID:        event.ID,
+		EventType:      event.Type,
+		Payload:        string(body),
+		RequestID:      event.RequestID,
+		CorrelationID:  event.CorrelationID,
+		Status:         Models.WebhookDeliveryPending,
+	}, nil
+}
+
+// matchWebhookFilter 事件内容是否满足筛选条件，条件为空时全部满足
+func matchWebhookFilter(filter string, payload json.RawMessage) bool {
+	if strings.TrimSpace(filter) == "" {
+		return true
+	}
+	var conditions map[string]interface{}
+	if err := json.Unmarshal([]byte(filter), &conditions); err != nil || len(conditions) == 0 {
+		return true
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return false
+	}
+
+	for field, expected := range conditions {
+		actual, ok := data[field]
+		if !ok {
+			return false
+		}
+		candidates, isList := expected.([]interface{})
+		if !isList {
+			candidates = []interface{}{expected}
+		}
+		matched := false
+		for _, candidate := range candidates {
+			if fmt.Sprint(candidate) == fmt.Sprint(actual) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// SignWebhookPayload 计算请求签名：sha256=hex(HMAC-SHA256(secret, timestamp + "." + body))
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Deliver 发送一条投递记录，记录不是待发送状态（已被其他协程领取或已完成）时忽略
+func (s *WebhookService) Deliver(ctx context.Context, id uint) error {
+	result := s.db.Model(&Models.WebhookDelivery{}).
+		Where("id = ? AND status IN ?", id, []string{Models.WebhookDeliveryPending, Models.WebhookDeliveryRetrying}).
+		Updates(map[string]interface{}{
+			"status":   Models.WebhookDeliveryDelivering,
+			"attempts": gorm.Expr("attempts + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	var record Models.WebhookDelivery
+	if err := s.db.First(&record, id).Error; err != nil {
+		return err
+	}
+	var subscription Models.WebhookSubscription
+	if err := s.db.First(&subscription, record.SubscriptionID).Error; err != nil {
+		s.finish(&record, nil, Models.WebhookDeliveryFailed, 0, "", 0, errors.New("订阅已删除"))
+		return err
+	}
+	// 领取后订阅被暂停，放回待发送，不计入发送次数
+	if subscription.Status != Models.WebhookSubscriptionActive {
+		return s.db.Model(&Models.WebhookDelivery{}).Where("id = ?", record.ID).Updates(map[string]interface{}{
+			"status":   Models.WebhookDeliveryPending,
+			"attempts": gorm.Expr("attempts - 1"),
+		}).Error
+	}
+
+	statusCode, responseBody, duration, err := s.send(ctx, &subscription, &record)
+	if err == nil {
+		s.finish(&record, &subscription, Models.WebhookDeliverySucceeded, statusCode, responseBody, duration, nil)
+		return nil
+	}
+	if record.Attempts >= s.config.MaxAttempts {
+		s.finish(&record, &subscription, Models.WebhookDeliveryFailed, statusCode, responseBody, duration, err)
+		return err
+	}
+	s.finish(&record, &subscription, Models.WebhookDeliveryRetrying, statusCode, responseBody, duration, err)
+	return err
+}
+
+// send 发送请求，非2xx响应视为失败
+func (s *WebhookService) send(ctx context.Context, subscription *Models.WebhookSubscription, record *Models.WebhookDelivery) (int, string, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	body := []byte(record.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", 0, fmt.Errorf("创建Webhook请求失败: %v", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "cloud-platform-api-webhooks/1.0")
+	req.Header.Set(WebhookHeaderEvent, record.EventType)
+	req.Header.Set(WebhookHeaderDelivery, record.DeliveryID)
+	req.Header.Set(WebhookHeaderTimestamp, timestamp)
+	req.Header.Set(WebhookHeaderSignature, SignWebhookPayload(subscription.Secret, timestamp, body))
+	// 接收方可以用请求ID和关联ID关联到产生事件的请求
+	Utils.SetRequestIDHeaders(req.Header, record.RequestID, record.CorrelationID)
+
+	start := time.Now()
+	resp, err := s.client.Do(s.webhookDependency(subscription.URL), req)
+	if err != nil {
+		return 0, "", time.Since(start), err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	io.Copy(io.Discard, resp.Body)
+	duration := time.Since(start)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(data), duration, fmt.Errorf("接收方返回状态码 %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(data), duration, nil
+}
+
+// finish 保存本次发送结果，待重试时按 RetryBackoff * 2^(n-1) 安排下次发送
+func (s *WebhookService) finish(record *Models.WebhookDelivery, subscription *Models.WebhookSubscription, status string, statusCode int, responseBody string, duration time.Duration, cause error) {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":          status,
+		"response_status": statusCode,
+		"response_body":   truncateString(responseBody, webhookResponseLimit),
+		"duration_ms":     duration.Milliseconds(),
+		"next_attempt_at": nil,
+		"last_error":      "",
+	}
+	if cause != nil {
+		updates["last_error"] = cause.Error()
+	}
+	var delay time.Duration
+	switch status {
+	case Models.WebhookDeliverySucceeded:
+		updates["delivered_at"] = now
+	case Models.WebhookDeliveryRetrying:
+		delay = s.backoff(record.Attempts)
+		updates["next_attempt_at"] = now.Add(delay)
+	}
+	if err := s.db.Model(&Models.WebhookDelivery{}).Where("id = ?", record.ID).Updates(updates).Error; err != nil {
+		log.Printf("更新Webhook投递状态失败: id=%d, error=%v", record.ID, err)
+		return
+	}
+
+	if subscription != nil {
+		subscriptionUpdates := map[string]interface{}{"last_delivery_at": now}
+		if status == Models.WebhookDeliverySucceeded {
+			subscriptionUpdates["last_delivery_status"] = Models.WebhookDeliverySucceeded
+			subscriptionUpdates["consecutive_failures"] = 0
+		} else {
+			subscriptionUpdates["last_delivery_status"] = Models.WebhookDeliveryFailed
+			subscriptionUpdates["consecutive_failures"] = gorm.Expr("consecutive_failures + 1")
+		}
+		s.db.Model(&Models.WebhookSubscription{}).Where("id = ?", subscription.ID).Updates(subscriptionUpdates)
+	}
+
+	if status == Models.WebhookDeliveryRetrying {
+		id := record.ID
+		time.AfterFunc(delay, func() { s.enqueue(id) })
+	}
+}
+
+// backoff 第n次发送失败后的等待时间
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	delay := s.config.RetryBackoff
+	for i := 1; i < attempts && delay < s.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, s.config.MaxBackoff)
+}
+
+// Purge 分批删除超过保留时间的已完成投递记录，由清理调度器定期运行
+func (s *WebhookService) Purge(ctx context.Context) (int64, error) {
+	if s.config.Retention <= 0 {
+		return 0, nil
+	}
+	query := s.db.Where("status IN ? AND created_at < ?",
+		[]string{Models.WebhookDeliverySucceeded, Models.WebhookDeliveryFailed}, time.Now().Add(-s.config.Retention))
+	return BatchDelete(ctx, query, &Models.WebhookDelivery{})
+}
+
+// GetSubscription 获取订阅
+func (s *WebhookService) GetSubscription(id uint) (*Models.WebhookSubscription, error) {
+	var subscription Models.WebhookSubscription
+	if err := s.db.First(&subscription, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookSubscriptionNotFound
+		}
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// ListSubscriptions 分页查询订阅
+func (s *WebhookService) ListSubscriptions(q *Utils.ListQuery, req Utils.PageRequest) ([]Models.WebhookSubscription, Utils.PageMeta, error) {
+	query, order, err := q.Apply(s.db.Model(&Models.WebhookSubscription{}), req)
+	if err != nil {
+		return nil, Utils.PageMeta{}, err
+	}
+	var subscriptions []Models.WebhookSubscription
+	meta, err := Utils.Paginate(query, req, order, &subscriptions)
+	return subscriptions, meta, err
+}
+
+// CreateSubscription 创建订阅，未指定密钥时自动生成，返回的订阅 Secret 字段为明文密钥
+func (s *WebhookService) CreateSubscription(input WebhookSubscriptionInput, userID uint) (*Models.WebhookSubscription, error) {
+	subscription := &Models.WebhookSubscription{
+		Status:    Models.WebhookSubscriptionActive,
+		CreatedBy: userID,
+	}
+	if err := s.applyInput(subscription, input); err != nil {
+		return nil, err
+	}
+	if subscription.Secret == "" {
+		subscription.Secret = generateWebhookSecret()
+	}
+	if err := s.db.Create(subscription).Error; err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// UpdateSubscription 更新订阅，不修改状态；input.Secret 为空时保留原密钥
+func (s *WebhookService) UpdateSubscription(id uint, input WebhookSubscriptionInput) (*Models.WebhookSubscription, error) {
+	subscription, err := s.GetSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyInput(subscription, input); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(subscription).Error; err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// applyInput 校验参数并写入订阅
+func (s *WebhookService) applyInput(subscription *Models.WebhookSubscription, input WebhookSubscriptionInput) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len([]rune(name)) > 100 {
+		return fmt.Errorf("%w：名称不能为空且不超过100个字符", ErrInvalidWebhookSubscription)
+	}
+	if err := s.validateURL(input.URL); err != nil {
+		return err
+	}
+
+	var eventTypes []string
+	seen := make(map[string]bool)
+	for _, eventType := range input.EventTypes {
+		eventType = strings.TrimSpace(eventType)
+		if eventType == "" || seen[eventType] {
+			continue
+		}
+		if !isWebhookEventPattern(eventType) {
+			return fmt.Errorf("%w：不支持的事件类型 %s", ErrInvalidWebhookSubscription, eventType)
+		}
+		seen[eventType] = true
+		eventTypes = append(eventTypes, eventType)
+	}
+	if len(eventTypes) == 0 {
+		return fmt.Errorf("%w：至少订阅一个事件类型", ErrInvalidWebhookSubscription)
+	}
+
+	filter := ""
+	if len(input.Filter) > 0 {
+		data, err := json.Marshal(input.Filter)
+		if err != nil {
+			return fmt.Errorf("%w：筛选条件无效", ErrInvalidWebhookSubscription)
+		}
+		filter = string(data)
+	}
+
+	if input.Secret != "" {
+		if len(input.Secret) < 16 || len(input.Secret) > 128 {
+			return fmt.Errorf("%w：签名密钥长度必须在16到128个字符之间", ErrInvalidWebhookSubscription)
+		}
+		subscription.Secret = input.Secret
+	}
+
+	subscription.Name = name
+	subscription.Description = strings.TrimSpace(input.Description)
+	subscription.URL = strings.TrimSpace(input.URL)
+	subscription.EventTypes = strings.Join(eventTypes, ",")
+	subscription.Filter = filter
+	return nil
+}
+
+// validateURL 检查接收地址，只允许 http/https；不允许内网时拒绝内网IP和 localhost
+func (s *WebhookService) validateURL(raw string) error {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return fmt.Errorf("%w：接收地址必须是有效的http或https地址", ErrInvalidWebhookSubscription)
+	}
+	if s.config.AllowPrivateNetworks {
+		return nil
+	}
+	host := strings.ToLower(parsed.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrWebhookPrivateAddress
+	}
+	if ip := net.ParseIP(host); ip != nil && isPrivateWebhookIP(ip) {
+		return ErrWebhookPrivateAddress
+	}
+	return nil
+}
+
+// isWebhookEventPattern 是否为可订阅的事件类型或通配
+func isWebhookEventPattern(pattern string) bool {
+	if pattern == "*" {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		for _, eventType := range WebhookEventTypes {
+			if strings.HasPrefix(eventType.Type, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, eventType := range WebhookEventTypes {
+		if eventType.Type == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// generateWebhookSecret 生成签名密钥
+func generateWebhookSecret() string {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "whsec_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	}
+	return "whsec_" + hex.EncodeToString(buf)
+}
+
+// DeleteSubscription 删除订阅及其投递记录
+func (s *WebhookService) DeleteSubscription(id uint) error {
+	if _, err := s.GetSubscription(id); err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("subscription_id = ?", id).Delete(&Models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Models.WebhookSubscription{}, id).Error
+	})
+}
+
+// PauseSubscription 暂停订阅，新事件照常生成投递记录，恢复后发送
+func (s *WebhookService) PauseSubscription(id uint) (*Models.WebhookSubscription, error) {
+	if _, err := s.GetSubscription(id); err != nil {
+		return nil, err
+	}
+	err := s.db.Model(&Models.WebhookSubscription{}).
+		Where("id = ? AND status = ?", id, Models.WebhookSubscriptionActive).
+		Updates(map[string]interface{}{"status": Models.WebhookSubscriptionPaused, "paused_at": time.Now()}).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.GetSubscription(id)
+}
+
+// ResumeSubscription 恢复订阅，并发送暂停期间积压的投递
+func (s *WebhookService) ResumeSubscription(id uint) (*Models.WebhookSubscription, error) {
+	if _, err := s.GetSubscription(id); err != nil {
+		return nil, err
+	}
+	err := s.db.Model(&Models.WebhookSubscription{}).
+		Where("id = ? AND status = ?", id, Models.WebhookSubscriptionPaused).
+		Updates(map[string]interface{}{"status": Models.WebhookSubscriptionActive, "paused_at": nil}).Error
+	if err != nil {
+		return nil, err
+	}
+	s.enqueueDue(id)
+	return s.GetSubscription(id)
+}
+
+// RotateSecret 生成新的签名密钥，返回的订阅 Secret 字段为新密钥
+func (s *WebhookService) RotateSecret(id uint) (*Models.WebhookSubscription, error) {
+	subscription, err := s.GetSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	subscription.Secret = generateWebhookSecret()
+	if err := s.db.Model(subscription).Update("secret", subscription.Secret).Error; err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// SendTestEvent 向订阅发送一个 webhook.ping 测试事件，暂停的订阅恢复后才会发送
+func (s *WebhookService) SendTestEvent(id uint) (*Models.WebhookDelivery, error) {
+	subscription, err := s.GetSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	payload, _ := json.Marshal(map[string]interface{}{
+		"subscription_id": subscription.ID,
+		"name":            subscription.Name,
+	})
+	delivery, err := newWebhookDelivery(subscription.ID, DomainEvent{
+		ID:            uuid.NewString(),
+		Type:          WebhookEventPing,
+		AggregateType: "webhook_subscription",
+		AggregateID:   fmt.Sprint(subscription.ID),
+		Payload:       payload,
+		OccurredAt:    time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(delivery).Error; err != nil {
+		return nil, err
+	}
+	s.enqueue(delivery.ID)
+	return delivery, nil
+}
+
+// ListDeliveries 分页查询订阅的投递记录
+func (s *WebhookService) ListDeliveries(subscriptionID uint, q *Utils.ListQuery, req Utils.PageRequest) ([]Models.WebhookDelivery, Utils.PageMeta, error) {
+	if _, err := s.GetSubscription(subscriptionID); err != nil {
+		return nil, Utils.PageMeta{}, err
+	}
+	base := s.db.Model(&Models.WebhookDelivery{}).Where("subscription_id = ?", subscriptionID)
+	query, order, err := q.Apply(base, req)
+	if err != nil {
+		return nil, Utils.PageMeta{}, err
+	}
+	var deliveries []Models.WebhookDelivery
+	meta, err := Utils.Paginate(query, req, order, &deliveries)
+	return deliveries, meta, err
+}
+
+// GetDelivery 获取订阅的投递记录
+func (s *WebhookService) GetDelivery(subscriptionID, id uint) (*Models.WebhookDelivery, error) {
+	var delivery Models.WebhookDelivery
+	if err := s.db.Where("id = ? AND subscription_id = ?", id, subscriptionID).First(&delivery).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// RetryDelivery 手动重试发送失败的投递，重置发送次数
+func (s *WebhookService) RetryDelivery(subscriptionID, id uint) (*Models.WebhookDelivery, error) {
+	if _, err := s.GetDelivery(subscriptionID, id); err != nil {
+		return nil, err
+	}
+	result := s.db.Model(&Models.WebhookDelivery{}).
+		Where("id = ? AND status = ?", id, Models.WebhookDeliveryFailed).
+		Updates(map[string]interface{}{
+			"status":          Models.WebhookDeliveryPending,
+			"attempts":        0,
+			"next_attempt_at": nil,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrWebhookDeliveryNotRetryable
+	}
+	s.enqueue(id)
+	return s.GetDelivery(subscriptionID, id)
+}
@@ -0,0 +1,203 @@
+// Package LoadTest 接口压测和性能回归检查
+// 功能说明：
+// 1. 按场景并发发送请求，记录每个请求的耗时，计算 P50/P90/P95/P99 等延迟分位数和吞吐量
+// 2. 请求可以发往进程内的 http.Handler（测试中直接压测 gin 引擎）或运行中的服务地址
+// 3. 压测报告可以保存为基线文件，之后的报告与基线比较，P95 超出容忍范围或出现请求失败时视为回归
+//
+// 与 Testing.PerformanceTest 的区别：PerformanceTest 按持续时间压测任意函数并断言固定阈值，
+// 本包按请求数压测 HTTP 接口并与历史基线比较，用于在 CI 中发现中间件和服务改动带来的性能退化
+package LoadTest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Scenario 压测场景
+type Scenario struct {
+	Name   string      // 场景名称，基线文件按名称对应
+	Method string      // 请求方法，为空时为 GET
+	Path   string      // 请求路径，可包含查询参数
+	Body   []byte      // 请求体
+	Header http.Header // 请求头
+	Expect int         // 期望的状态码，为 0 时接受 2xx
+}
+
+// Options 压测参数
+type Options struct {
+	Concurrency int           // 并发数，默认 10
+	Requests    int           // 每个场景的请求数，默认 200
+	Warmup      int           // 每个场景的预热请求数，不计入统计，默认 10，负数表示不预热
+	Timeout     time.Duration // 单个请求的超时时间，默认 10 秒
+}
+
+// withDefaults 补全未设置的参数
+func (o Options) withDefaults() Options {
+	if o.Concurrency <= 0 {
+		o.Concurrency = 10
+	}
+	if o.Requests <= 0 {
+		o.Requests = 200
+	}
+	if o.Warmup < 0 {
+		o.Warmup = 0
+	} else if o.Warmup == 0 {
+		o.Warmup = 10
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	return o
+}
+
+// Generator 压测请求发送器
+// 功能说明：
+// 1. 场景依次执行，每个场景先顺序发送预热请求，再由 Concurrency 个协程共同发送 Requests 个请求
+// 2. 请求出错、超时或状态码不符合期望时计为失败，失败请求的耗时同样计入延迟统计
+type Generator struct {
+	handler http.Handler
+	baseURL string
+	client  *http.Client
+	options Options
+}
+
+// NewGenerator 创建压测进程内 http.Handler 的发送器
+func NewGenerator(handler http.Handler, options Options) *Generator {
+	return &Generator{handler: handler, options: options.withDefaults()}
+}
+
+// NewRemoteGenerator 创建压测运行中服务的发送器，baseURL 如 http://127.0.0.1:8080
+func NewRemoteGenerator(baseURL string, options Options) *Generator {
+	options = options.withDefaults()
+	return &Generator{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client: &http.Client{
+			Timeout: options.Timeout,
+			Transport: &http.Transport{
+				MaxIdleConns:        options.Concurrency * 2,
+				MaxIdleConnsPerHost: options.Concurrency * 2,
+				IdleConnTimeout:     30 * time.Second,
+			},
+		},
+		options: options,
+	}
+}
+
+// Run 依次执行场景并返回报告，ctx 取消时停止发送请求，已完成的请求仍计入报告
+func (g *Generator) Run(ctx context.Context, scenarios ...Scenario) *Report {
+	report := &Report{
+		GeneratedAt: time.Now(),
+		Concurrency: g.options.Concurrency,
+		Scenarios:   make([]*ScenarioResult, 0, len(scenarios)),
+	}
+	for _, scenario := range scenarios {
+		report.Scenarios = append(report.Scenarios, g.runScenario(ctx, scenario))
+	}
+	return report
+}
+
+// runScenario 执行单个场景
+func (g *Generator) runScenario(ctx context.Context, scenario Scenario) *ScenarioResult {
+	for i := 0; i < g.options.Warmup && ctx.Err() == nil; i++ {
+		g.do(ctx, scenario)
+	}
+
+	latencies := make([]time.Duration, 0, g.options.Requests)
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		next      int64
+		failures  int64
+		lastError atomic.Value
+	)
+	start := time.Now()
+	for w := 0; w < g.options.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for atomic.AddInt64(&next, 1) <= int64(g.options.Requests) && ctx.Err() == nil {
+				latency, err := g.do(ctx, scenario)
+				if err != nil {
+					atomic.AddInt64(&failures, 1)
+					lastError.Store(err.Error())
+				}
+				mu.Lock()
+				latencies = append(latencies, latency)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	result := newScenarioResult(scenario.Name, latencies, time.Since(start))
+	result.Errors = int(failures)
+	if result.Requests > 0 {
+		result.ErrorRate = float64(result.Errors) / float64(result.Requests)
+	}
+	if message, ok := lastError.Load().(string); ok {
+		result.LastError = message
+	}
+	return result
+}
+
+// do 发送一个请求，返回耗时和失败原因
+func (g *Generator) do(ctx context.Context, scenario Scenario) (time.Duration, error) {
+	method := scenario.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	var body io.Reader
+	if scenario.Body != nil {
+		body = bytes.NewReader(scenario.Body)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, g.options.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, method, g.baseURL+scenario.Path, body)
+	if err != nil {
+		return 0, err
+	}
+	for key, values := range scenario.Header {
+		req.Header[key] = values
+	}
+	if scenario.Body != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	start := time.Now()
+	status, err := g.send(req)
+	latency := time.Since(start)
+	if err != nil {
+		return latency, err
+	}
+	if (scenario.Expect == 0 && (status < 200 || status >= 300)) || (scenario.Expect != 0 && status != scenario.Expect) {
+		return latency, fmt.Errorf("%s %s 返回状态码 %d", method, scenario.Path, status)
+	}
+	return latency, nil
+}
+
+// send 发送请求并读完响应体，返回状态码
+func (g *Generator) send(req *http.Request) (int, error) {
+	if g.handler != nil {
+		recorder := httptest.NewRecorder()
+		g.handler.ServeHTTP(recorder, req)
+		return recorder.Code, nil
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return resp.StatusCode, err
+	}
+	return resp.StatusCode, nil
+}
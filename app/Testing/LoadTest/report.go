@@ -0,0 +1,163 @@
+package LoadTest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Report 压测报告，也用作基线文件
+type Report struct {
+	GeneratedAt time.Time         `json:"generated_at"`
+	Concurrency int               `json:"concurrency"`
+	Scenarios   []*ScenarioResult `json:"scenarios"`
+}
+
+// ScenarioResult 单个场景的压测结果，延迟以纳秒保存
+type ScenarioResult struct {
+	Name       string        `json:"name"`
+	Requests   int           `json:"requests"`
+	Errors     int           `json:"errors"`
+	ErrorRate  float64       `json:"error_rate"`
+	Throughput float64       `json:"throughput"` // 每秒请求数
+	Mean       time.Duration `json:"mean_ns"`
+	P50        time.Duration `json:"p50_ns"`
+	P90        time.Duration `json:"p90_ns"`
+	P95        time.Duration `json:"p95_ns"`
+	P99        time.Duration `json:"p99_ns"`
+	Max        time.Duration `json:"max_ns"`
+	LastError  string        `json:"last_error,omitempty"`
+}
+
+// newScenarioResult 由请求耗时计算延迟分位数和吞吐量
+func newScenarioResult(name string, latencies []time.Duration, elapsed time.Duration) *ScenarioResult {
+	result := &ScenarioResult{Name: name, Requests: len(latencies)}
+	if len(latencies) == 0 {
+		return result
+	}
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, latency := range sorted {
+		total += latency
+	}
+	result.Mean = total / time.Duration(len(sorted))
+	result.P50 = Percentile(sorted, 50)
+	result.P90 = Percentile(sorted, 90)
+	result.P95 = Percentile(sorted, 95)
+	result.P99 = Percentile(sorted, 99)
+	result.Max = sorted[len(sorted)-1]
+	if elapsed > 0 {
+		result.Throughput = float64(len(sorted)) / elapsed.Seconds()
+	}
+	return result
+}
+
+// Percentile 按最近秩法计算升序耗时的分位数，p 取 0-100
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	rank = min(max(rank, 1), len(sorted))
+	return sorted[rank-1]
+}
+
+// Scenario 按名称查找场景结果
+func (r *Report) Scenario(name string) *ScenarioResult {
+	for _, scenario := range r.Scenarios {
+		if scenario.Name == name {
+			return scenario
+		}
+	}
+	return nil
+}
+
+// LoadReport 读取报告或基线文件
+func LoadReport(filename string) (*Report, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("解析压测报告 %s 失败: %v", filename, err)
+	}
+	return &report, nil
+}
+
+// WriteFile 把报告写入文件，目录不存在时创建
+func (r *Report) WriteFile(filename string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(filename); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(filename, append(data, '\n'), 0644)
+}
+
+// GateOptions 性能回归检查参数
+type GateOptions struct {
+	Tolerance    float64       // P95 允许超出基线的比例，如 0.2 表示 20%
+	MinDelta     time.Duration // P95 超出基线不到该值时不视为回归，避免亚毫秒接口的抖动误报
+	MaxErrorRate float64       // 允许的请求失败率
+}
+
+// Regression 性能回归
+type Regression struct {
+	Scenario string
+	Metric   string // p95 或 error_rate
+	Baseline float64
+	Current  float64
+}
+
+// String 回归说明
+func (r Regression) String() string {
+	if r.Metric == "error_rate" {
+		return fmt.Sprintf("%s: 请求失败率 %.2f%% 超过允许的 %.2f%%", r.Scenario, r.Current*100, r.Baseline*100)
+	}
+	return fmt.Sprintf("%s: P95 由 %v 增加到 %v (+%.1f%%)", r.Scenario,
+		time.Duration(r.Baseline), time.Duration(r.Current), (r.Current/r.Baseline-1)*100)
+}
+
+// Compare 与基线比较，返回性能回归
+// 功能说明：
+// 1. P95 同时超过 基线×(1+Tolerance) 和 基线+MinDelta 时视为回归
+// 2. 请求失败率超过 MaxErrorRate 时视为回归，失败的请求可能很快返回而掩盖延迟变化
+// 3. 基线中没有的场景只检查失败率
+func (r *Report) Compare(baseline *Report, options GateOptions) []Regression {
+	var regressions []Regression
+	for _, current := range r.Scenarios {
+		if current.ErrorRate > options.MaxErrorRate {
+			regressions = append(regressions, Regression{
+				Scenario: current.Name,
+				Metric:   "error_rate",
+				Baseline: options.MaxErrorRate,
+				Current:  current.ErrorRate,
+			})
+		}
+		base := baseline.Scenario(current.Name)
+		if base == nil || base.P95 <= 0 {
+			continue
+		}
+		limit := time.Duration(float64(base.P95) * (1 + options.Tolerance))
+		if current.P95 > limit && current.P95-base.P95 > options.MinDelta {
+			regressions = append(regressions, Regression{
+				Scenario: current.Name,
+				Metric:   "p95",
+				Baseline: float64(base.P95),
+				Current:  float64(current.P95),
+			})
+		}
+	}
+	return regressions
+}
@@ -0,0 +1,47 @@
+package LoadTest
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// 关键接口场景名称
+const (
+	ScenarioLogin   = "login"
+	ScenarioMetrics = "metrics"
+	ScenarioAlerts  = "alerts"
+)
+
+// Credentials 登录场景使用的账号
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// KeyScenarios 关键接口的压测场景：登录、指标查询和告警列表
+// token 不为空时指标和告警接口携带 Bearer Token；names 为空时返回全部场景，否则只返回指定名称的场景
+func KeyScenarios(credentials Credentials, token string, names ...string) []Scenario {
+	login, _ := json.Marshal(map[string]string{"username": credentials.Username, "password": credentials.Password})
+	header := http.Header{}
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+
+	all := []Scenario{
+		{Name: ScenarioLogin, Method: http.MethodPost, Path: "/api/v1/auth/login", Body: login},
+		{Name: ScenarioMetrics, Path: "/api/v1/monitoring/metrics", Header: header},
+		{Name: ScenarioAlerts, Path: "/api/v1/monitoring/alerts", Header: header},
+	}
+	if len(names) == 0 {
+		return all
+	}
+	var selected []Scenario
+	for _, name := range names {
+		for _, scenario := range all {
+			if scenario.Name == name {
+				selected = append(selected, scenario)
+			}
+		}
+	}
+	return selected
+}
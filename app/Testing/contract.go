@@ -0,0 +1,178 @@
+package Testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"cloud-platform-api/app/OpenAPI"
+)
+
+// Contract 接口契约测试
+// 功能说明：
+// 1. 通过 gin 引擎发送请求，每个响应自动按 OpenAPI 文档校验状态码和响应体，不符合时测试失败
+// 2. 响应结构可以与黄金文件比对（见 AssertGolden），接口字段增减或类型变化时测试失败
+// 3. WithToken、WithHeader 返回副本，同一个 Contract 可以用不同身份发送请求
+//
+// 使用示例：
+//
+//	contract := Testing.NewContract(t, engine, registry).WithToken(token)
+//	contract.Do(http.MethodPost, "/api/v1/admin/webhooks", input).AssertStatus(http.StatusCreated).AssertGolden("webhooks_create")
+type Contract struct {
+	t         testing.TB
+	engine    *gin.Engine
+	registry  *OpenAPI.Registry
+	headers   http.Header
+	goldenDir string
+}
+
+// NewContract 创建契约测试，registry 为空时使用默认接口文档
+func NewContract(t testing.TB, engine *gin.Engine, registry *OpenAPI.Registry) *Contract {
+	if registry == nil {
+		registry = OpenAPI.DefaultRegistry()
+	}
+	return &Contract{t: t, engine: engine, registry: registry, headers: make(http.Header), goldenDir: DefaultGoldenDir}
+}
+
+// WithToken 返回使用 Bearer Token 认证的副本
+func (c *Contract) WithToken(token string) *Contract {
+	return c.WithHeader("Authorization", "Bearer "+token)
+}
+
+// WithHeader 返回设置了请求头的副本
+func (c *Contract) WithHeader(key, value string) *Contract {
+	clone := *c
+	clone.headers = c.headers.Clone()
+	clone.headers.Set(key, value)
+	return &clone
+}
+
+// WithGoldenDir 返回使用指定黄金文件目录的副本
+func (c *Contract) WithGoldenDir(dir string) *Contract {
+	clone := *c
+	clone.goldenDir = dir
+	return &clone
+}
+
+// Do 发送请求并按接口文档校验响应
+// body 为 nil 时不发送请求体，为 string 或 []byte 时原样发送，其他类型编码为JSON
+func (c *Contract) Do(method, target string, body interface{}) *ContractResponse {
+	c.t.Helper()
+
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reader = strings.NewReader(b)
+	case []byte:
+		reader = bytes.NewReader(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			c.t.Fatalf("编码请求体失败: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, target, reader)
+	for key, values := range c.headers {
+		req.Header[key] = values
+	}
+	if reader != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	recorder := httptest.NewRecorder()
+	c.engine.ServeHTTP(recorder, req)
+
+	response := &ContractResponse{ResponseRecorder: recorder, contract: c, Method: method, Route: c.route(method, req.URL.Path)}
+	if response.Route != "" {
+		if err := c.registry.ValidateResponse(method, response.Route, recorder.Code, recorder.Body.Bytes()); err != nil {
+			c.t.Errorf("%s %s 的响应%v\n响应体: %s", method, target, err, recorder.Body.String())
+		}
+	}
+	return response
+}
+
+// route 查找请求匹配的 gin 路由路径，静态段优先于参数段，与 gin 的匹配规则一致
+func (c *Contract) route(method, path string) string {
+	best, bestScore := "", -1
+	for _, info := range c.engine.Routes() {
+		if info.Method != method {
+			continue
+		}
+		if score, ok := matchRoute(info.Path, path); ok && score > bestScore {
+			best, bestScore = info.Path, score
+		}
+	}
+	return best
+}
+
+// matchRoute 判断路径是否匹配路由，score 为匹配的静态段数
+func matchRoute(pattern, path string) (int, bool) {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	score := 0
+	for i, segment := range patternSegments {
+		if strings.HasPrefix(segment, "*") {
+			return score, true
+		}
+		if i >= len(pathSegments) {
+			return 0, false
+		}
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			if pathSegments[i] == "" {
+				return 0, false
+			}
+		case segment == pathSegments[i]:
+			score++
+		default:
+			return 0, false
+		}
+	}
+	return score, len(patternSegments) == len(pathSegments)
+}
+
+// ContractResponse 契约测试的响应
+type ContractResponse struct {
+	*httptest.ResponseRecorder
+	contract *Contract
+	Method   string
+	Route    string // 匹配的 gin 路由路径，没有匹配的路由时为空
+}
+
+// AssertStatus 断言响应状态码
+func (r *ContractResponse) AssertStatus(status int) *ContractResponse {
+	r.contract.t.Helper()
+	if r.Code != status {
+		r.contract.t.Errorf("%s %s 的响应状态码为 %d，期望 %d\n响应体: %s", r.Method, r.Route, r.Code, status, r.Body.String())
+	}
+	return r
+}
+
+// AssertGolden 断言响应结构与黄金文件一致，name 为文件名（不含扩展名）
+func (r *ContractResponse) AssertGolden(name string) *ContractResponse {
+	r.contract.t.Helper()
+	AssertGoldenShape(r.contract.t, r.contract.goldenDir, name, r.Body.Bytes())
+	return r
+}
+
+// Decode 把响应的 data 字段解码到 v
+func (r *ContractResponse) Decode(v interface{}) {
+	r.contract.t.Helper()
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(r.Body.Bytes(), &envelope); err != nil {
+		r.contract.t.Fatalf("解析响应失败: %v\n响应体: %s", err, r.Body.String())
+	}
+	if err := json.Unmarshal(envelope.Data, v); err != nil {
+		r.contract.t.Fatalf("解析响应数据失败: %v\n响应体: %s", err, r.Body.String())
+	}
+}
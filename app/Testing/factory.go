@@ -0,0 +1,189 @@
+package Testing
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Utils"
+)
+
+// DefaultPassword 工厂创建的用户的默认密码
+const DefaultPassword = "Password123!"
+
+// Factory 测试数据工厂
+// 功能说明：
+// 1. 按合理的默认值创建用户、告警规则、告警和指标，用户名、规则名等唯一字段按序号生成
+// 2. 每个方法接受修改函数，在保存前覆盖默认值，测试只需写出关心的字段
+// 3. 保存失败时测试立即失败，调用方不需要检查错误
+//
+// 使用示例：
+//
+//	factory := Testing.NewFactory(t, db)
+//	admin := factory.Admin()
+//	alert := factory.Alert(nil, func(a *Models.Alert) { a.Severity = "critical" })
+type Factory struct {
+	t   testing.TB
+	db  *gorm.DB
+	mu  sync.Mutex
+	seq int
+}
+
+// NewFactory 创建测试数据工厂
+func NewFactory(t testing.TB, db *gorm.DB) *Factory {
+	return &Factory{t: t, db: db}
+}
+
+// next 下一个序号
+func (f *Factory) next() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seq++
+	return f.seq
+}
+
+// create 保存记录，失败时测试立即失败
+func (f *Factory) create(value interface{}) {
+	f.t.Helper()
+	if err := f.db.Create(value).Error; err != nil {
+		f.t.Fatalf("创建测试数据 %T 失败: %v", value, err)
+	}
+}
+
+// User 创建正常状态的普通用户，密码为 DefaultPassword
+func (f *Factory) User(overrides ...func(*Models.User)) *Models.User {
+	f.t.Helper()
+	n := f.next()
+	user := &Models.User{
+		Username: fmt.Sprintf("user%d", n),
+		Email:    fmt.Sprintf("user%d@example.com", n),
+		Role:     "user",
+		Status:   1,
+	}
+	if err := user.SetPassword(DefaultPassword); err != nil {
+		f.t.Fatalf("设置密码失败: %v", err)
+	}
+	for _, override := range overrides {
+		override(user)
+	}
+	f.create(user)
+	return user
+}
+
+// Admin 创建管理员用户
+func (f *Factory) Admin(overrides ...func(*Models.User)) *Models.User {
+	f.t.Helper()
+	return f.User(append([]func(*Models.User){func(u *Models.User) { u.Role = "admin" }}, overrides...)...)
+}
+
+// Token 为用户签发访问令牌，使用全局配置的JWT密钥
+func (f *Factory) Token(user *Models.User) string {
+	f.t.Helper()
+	config := Config.GetConfig()
+	if config == nil {
+		f.t.Fatalf("签发令牌前需要先调用 Config.LoadConfig")
+	}
+	token, err := Utils.NewJWTUtils(&config.JWT).GenerateToken(user.ID, user.Username, user.Email, user.Role)
+	if err != nil {
+		f.t.Fatalf("签发令牌失败: %v", err)
+	}
+	return token
+}
+
+// AlertRule 创建启用的阈值告警规则：cpu_usage > 80
+func (f *Factory) AlertRule(overrides ...func(*Models.AlertRule)) *Models.AlertRule {
+	f.t.Helper()
+	rule := &Models.AlertRule{
+		Name:               fmt.Sprintf("alert-rule-%d", f.next()),
+		Type:               "threshold",
+		MetricType:         "system",
+		MetricName:         "cpu_usage",
+		Condition:          ">",
+		Threshold:          80,
+		Duration:           1,
+		Severity:           "warning",
+		Enabled:            true,
+		SuppressionWindow:  3600,
+		EscalationDelay:    600,
+		MaxEscalationLevel: 3,
+	}
+	for _, override := range overrides {
+		override(rule)
+	}
+	f.create(rule)
+	return rule
+}
+
+// Alert 创建规则触发的活动告警，rule 为空时先创建一条规则
+func (f *Factory) Alert(rule *Models.AlertRule, overrides ...func(*Models.Alert)) *Models.Alert {
+	f.t.Helper()
+	if rule == nil {
+		rule = f.AlertRule()
+	}
+	now := time.Now()
+	alert := &Models.Alert{
+		RuleID:      rule.ID,
+		RuleName:    rule.Name,
+		Type:        rule.Type,
+		MetricType:  rule.MetricType,
+		MetricName:  rule.MetricName,
+		Value:       rule.Threshold + 10,
+		Threshold:   rule.Threshold,
+		Severity:    rule.Severity,
+		Status:      "active",
+		Message:     fmt.Sprintf("%s %s %v", rule.MetricName, rule.Condition, rule.Threshold),
+		Fingerprint: fmt.Sprintf("fingerprint-%d", f.next()),
+		Count:       1,
+		FiredAt:     now,
+		LastSeenAt:  now,
+	}
+	for _, override := range overrides {
+		override(alert)
+	}
+	f.create(alert)
+	return alert
+}
+
+// Metric 创建正常状态的系统指标
+func (f *Factory) Metric(overrides ...func(*Models.MonitoringMetric)) *Models.MonitoringMetric {
+	f.t.Helper()
+	metric := &Models.MonitoringMetric{
+		Type:      "system",
+		Name:      "cpu_usage",
+		Value:     50,
+		Unit:      "%",
+		Threshold: 80,
+		Status:    "normal",
+		Severity:  "info",
+		Timestamp: time.Now(),
+	}
+	for _, override := range overrides {
+		override(metric)
+	}
+	f.create(metric)
+	return metric
+}
+
+// MetricSamples 按每分钟一个采样创建指标历史，最后一个采样的时间为当前时间
+func (f *Factory) MetricSamples(name string, values ...float64) []Models.MetricSample {
+	f.t.Helper()
+	if len(values) == 0 {
+		return nil
+	}
+	now := time.Now()
+	samples := make([]Models.MetricSample, len(values))
+	for i, value := range values {
+		samples[i] = Models.MetricSample{
+			Name:      name,
+			Value:     value,
+			Timestamp: now.Add(-time.Duration(len(values)-1-i) * time.Minute),
+		}
+	}
+	f.create(&samples)
+	return samples
+}
@@ -0,0 +1,200 @@
+package Testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// DefaultGoldenDir 黄金文件的默认目录，相对于测试所在目录
+const DefaultGoldenDir = "testdata/contracts"
+
+// UpdateGoldenEnv 设置该环境变量为 1 时重新生成黄金文件
+// 例如：UPDATE_GOLDEN=1 go test ./tests/Contract/
+const UpdateGoldenEnv = "UPDATE_GOLDEN"
+
+// Shape JSON 响应的结构，只记录字段和类型，不记录取值
+// 功能说明：
+// 1. 类型为 object、array、string、integer、number、boolean、null，数组元素类型不一致时为 mixed
+// 2. 数组的元素结构合并所有元素：对象字段取并集，出现过 null 的字段标记 nullable
+// 3. ID、时间等每次运行都不同的取值不影响结构，黄金文件可以稳定比对
+type Shape struct {
+	Type       string            `json:"type"`
+	Nullable   bool              `json:"nullable,omitempty"`
+	Items      *Shape            `json:"items,omitempty"`
+	Properties map[string]*Shape `json:"properties,omitempty"`
+}
+
+// JSONShape 解析JSON并返回其结构
+func JSONShape(data []byte) (*Shape, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return shapeOf(value), nil
+}
+
+// shapeOf 返回已解析的JSON值的结构
+func shapeOf(value interface{}) *Shape {
+	switch v := value.(type) {
+	case nil:
+		return &Shape{Type: "null"}
+	case bool:
+		return &Shape{Type: "boolean"}
+	case string:
+		return &Shape{Type: "string"}
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return &Shape{Type: "integer"}
+		}
+		return &Shape{Type: "number"}
+	case []interface{}:
+		var items *Shape
+		for _, item := range v {
+			items = mergeShapes(items, shapeOf(item))
+		}
+		return &Shape{Type: "array", Items: items}
+	case map[string]interface{}:
+		s := &Shape{Type: "object", Properties: make(map[string]*Shape, len(v))}
+		for key, item := range v {
+			s.Properties[key] = shapeOf(item)
+		}
+		return s
+	default:
+		return &Shape{Type: "mixed"}
+	}
+}
+
+// mergeShapes 合并同一位置的两个结构
+func mergeShapes(a, b *Shape) *Shape {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case a.Type == "null":
+		merged := *b
+		merged.Nullable = true
+		return &merged
+	case b.Type == "null":
+		merged := *a
+		merged.Nullable = true
+		return &merged
+	}
+
+	merged := &Shape{Type: a.Type, Nullable: a.Nullable || b.Nullable}
+	switch {
+	case a.Type == b.Type:
+	case isNumeric(a.Type) && isNumeric(b.Type):
+		merged.Type = "number"
+	default:
+		merged.Type = "mixed"
+		return merged
+	}
+	switch merged.Type {
+	case "array":
+		merged.Items = mergeShapes(a.Items, b.Items)
+	case "object":
+		merged.Properties = make(map[string]*Shape, len(a.Properties))
+		for key, property := range a.Properties {
+			merged.Properties[key] = property
+		}
+		for key, property := range b.Properties {
+			merged.Properties[key] = mergeShapes(merged.Properties[key], property)
+		}
+	}
+	return merged
+}
+
+// AssertGoldenShape 断言JSON的结构与黄金文件 dir/name.json 一致
+// 1. 黄金文件不存在或设置了 UPDATE_GOLDEN=1 时写入当前结构；不存在时测试失败，提醒提交新生成的文件
+// 2. 字段缺失、新增或类型变化时测试失败，列出每处差异的位置，如 $.data.owner
+func AssertGoldenShape(t testing.TB, dir, name string, data []byte) {
+	t.Helper()
+	shape, err := JSONShape(data)
+	if err != nil {
+		t.Errorf("%s: 响应不是有效的JSON: %v", name, err)
+		return
+	}
+	actual, err := json.MarshalIndent(shape, "", "  ")
+	if err != nil {
+		t.Fatalf("%s: 编码响应结构失败: %v", name, err)
+	}
+	actual = append(actual, '\n')
+
+	filename := filepath.Join(dir, name+".json")
+	expected, err := os.ReadFile(filename)
+	if err != nil || os.Getenv(UpdateGoldenEnv) == "1" {
+		if writeErr := os.MkdirAll(dir, 0755); writeErr != nil {
+			t.Fatalf("创建黄金文件目录失败: %v", writeErr)
+		}
+		if writeErr := os.WriteFile(filename, actual, 0644); writeErr != nil {
+			t.Fatalf("写入黄金文件失败: %v", writeErr)
+		}
+		if err != nil {
+			t.Errorf("黄金文件 %s 不存在，已根据当前响应生成，请检查后提交", filename)
+		}
+		return
+	}
+
+	var golden Shape
+	if err := json.Unmarshal(expected, &golden); err != nil {
+		t.Fatalf("解析黄金文件 %s 失败: %v", filename, err)
+	}
+	if diffs := compareShapes(&golden, shape, "$"); len(diffs) > 0 {
+		t.Errorf("%s 的响应结构与黄金文件 %s 不一致（确认变更后使用 %s=1 重新生成）:\n%s",
+			name, filename, UpdateGoldenEnv, strings.Join(diffs, "\n"))
+	}
+}
+
+// compareShapes 比较黄金文件和实际响应的结构，返回差异
+// 取值为 null、空数组和整数与小数的区别取决于测试数据，不视为差异
+func compareShapes(expected, actual *Shape, at string) []string {
+	if expected == nil || actual == nil || expected.Type == "null" || actual.Type == "null" {
+		return nil
+	}
+	if expected.Type != actual.Type && !(isNumeric(expected.Type) && isNumeric(actual.Type)) {
+		return []string{fmt.Sprintf("%s: 类型由 %s 变为 %s", at, expected.Type, actual.Type)}
+	}
+
+	var diffs []string
+	switch expected.Type {
+	case "array":
+		diffs = compareShapes(expected.Items, actual.Items, at+"[]")
+	case "object":
+		keys := make([]string, 0, len(expected.Properties)+len(actual.Properties))
+		for key := range expected.Properties {
+			keys = append(keys, key)
+		}
+		for key := range actual.Properties {
+			if _, ok := expected.Properties[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			want, wantOK := expected.Properties[key]
+			got, gotOK := actual.Properties[key]
+			switch {
+			case !gotOK:
+				diffs = append(diffs, fmt.Sprintf("%s.%s: 字段缺失", at, key))
+			case !wantOK:
+				diffs = append(diffs, fmt.Sprintf("%s.%s: 新增字段", at, key))
+			default:
+				diffs = append(diffs, compareShapes(want, got, at+"."+key)...)
+			}
+		}
+	}
+	return diffs
+}
+
+func isNumeric(t string) bool {
+	return t == "integer" || t == "number"
+}
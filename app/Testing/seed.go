@@ -0,0 +1,29 @@
+package Testing
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+
+	"cloud-platform-api/app/Database/Seeders"
+)
+
+// SeedDemo 创建填充器需要的表并填充完整的演示租户，管理员和演示用户的密码为 DefaultPassword
+//
+// 适用于需要真实规模数据的集成测试，如仪表板、告警列表和指标查询；只关心个别记录时使用 Factory。
+func SeedDemo(t testing.TB, db *gorm.DB) *Seeders.Report {
+	t.Helper()
+	if err := db.AutoMigrate(Seeders.Tables()...); err != nil {
+		t.Fatalf("创建演示数据表失败: %v", err)
+	}
+	report, err := Seeders.NewSeederManager(db).Run(Seeders.EnvironmentDemo, Seeders.ForEnvironment(Seeders.EnvironmentDemo), Seeders.Options{
+		AdminUsername: "admin",
+		AdminEmail:    "admin@example.com",
+		AdminPassword: DefaultPassword,
+		DemoPassword:  DefaultPassword,
+	})
+	if err != nil {
+		t.Fatalf("填充演示数据失败: %v", err)
+	}
+	return report
+}
@@ -97,14 +97,23 @@ func ValidateEmailVerificationToken(tokenString string) (*Claims, error) {
 }
 
 // Claims JWT声明
+// 模拟令牌以目标用户身份签发，ImpersonationID 为模拟会话ID，ImpersonatorID 为发起模拟的管理员
 type Claims struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Role     string `json:"role"`
+	UserID           uint   `json:"user_id"`
+	Username         string `json:"username"`
+	Email            string `json:"email"`
+	Role             string `json:"role"`
+	ImpersonationID  string `json:"impersonation_id,omitempty"`
+	ImpersonatorID   uint   `json:"impersonator_id,omitempty"`
+	ImpersonatorName string `json:"impersonator,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// IsImpersonation 是否为管理员模拟登录签发的令牌
+func (c *Claims) IsImpersonation() bool {
+	return c.ImpersonationID != ""
+}
+
 // GenerateToken 生成JWT令牌
 //
 // 功能说明：
@@ -273,6 +282,11 @@ func (j *JWTUtils) RefreshToken(tokenString string) (string, error) {
 		return "", fmt.Errorf("验证令牌失败: %v", err)
 	}
 
+	// 模拟令牌有效期由模拟会话决定，不能刷新
+	if claims.IsImpersonation() {
+		return "", errors.New("模拟令牌不能刷新")
+	}
+
 	// 检查是否在刷新窗口内
 	refreshWindow := time.Duration(j.config.RefreshWindowHours) * time.Hour
 	if time.Until(claims.ExpiresAt.Time) > refreshWindow {
@@ -447,6 +461,38 @@ func (j *JWTUtils) GeneratePasswordResetToken(userID uint) (string, error) {
 	return tokenString, nil
 }
 
+// GenerateImpersonationToken 生成管理员模拟登录令牌
+// 功能说明：
+// 1. 令牌以目标用户的身份和角色签发，同时记录模拟会话ID和发起模拟的管理员
+// 2. 过期时间与模拟会话一致，主题为 impersonation_<会话ID>
+// 3. 认证中间件据此校验会话是否已结束，并为每个请求记录审计日志
+func (j *JWTUtils) GenerateImpersonationToken(userID uint, username, email, role, sessionID string, impersonatorID uint, impersonatorName string, expiresAt time.Time) (string, error) {
+	now := time.Now()
+	claims := &Claims{
+		UserID:           userID,
+		Username:         username,
+		Email:            email,
+		Role:             role,
+		ImpersonationID:  sessionID,
+		ImpersonatorID:   impersonatorID,
+		ImpersonatorName: impersonatorName,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    j.config.Issuer,
+			Subject:   fmt.Sprintf("impersonation_%s", sessionID),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString([]byte(j.config.SecretKey))
+	if err != nil {
+		return "", fmt.Errorf("生成模拟令牌失败: %v", err)
+	}
+	return tokenString, nil
+}
+
 // ValidatePasswordResetToken 验证密码重置令牌
 func (j *JWTUtils) ValidatePasswordResetToken(tokenString string) (*Claims, error) {
 	// 解析令牌
@@ -0,0 +1,255 @@
+package Utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// 分页模式
+const (
+	PageModeOffset = "offset" // 页码分页：OFFSET/LIMIT，可跳页，深分页时性能下降
+	PageModeCursor = "cursor" // 游标分页：按(排序列, 主键)做键集查询，翻页性能稳定
+)
+
+// ErrInvalidCursor 分页游标无法解析
+var ErrInvalidCursor = errors.New("无效的分页游标")
+
+// PageRequest 分页请求
+type PageRequest struct {
+	Mode      string // offset 或 cursor
+	Page      int    // 页码，从1开始，仅页码分页使用
+	Limit     int    // 每页数量
+	Cursor    string // 上一页返回的游标，为空表示第一页，仅游标分页使用
+	WithTotal bool   // 是否统计总数
+}
+
+// PageOrder 分页排序
+// Column 为排序的时间列，为空时只按主键排序；同一时间的记录始终按主键排序，保证翻页不重复不遗漏
+type PageOrder struct {
+	Column string
+	Asc    bool
+}
+
+// PageMeta 列表响应的分页信息
+type PageMeta struct {
+	Mode       string `json:"mode"`
+	PageSize   int    `json:"page_size"`
+	Page       int    `json:"page,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+	TotalPages *int   `json:"total_pages,omitempty"`
+	Cursor     string `json:"cursor,omitempty"` // 游标分页的下一页游标，没有更多数据时为空
+	HasMore    bool   `json:"has_more"`
+}
+
+// pageCursor 游标内容，编码为base64url的JSON，对客户端不透明
+type pageCursor struct {
+	Time *time.Time `json:"t,omitempty"`
+	Key  string     `json:"k"`
+}
+
+// EncodeCursor 生成游标
+func EncodeCursor(t *time.Time, key string) string {
+	data, _ := json.Marshal(pageCursor{Time: t, Key: key})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor 解析游标，返回排序列的值和主键
+func DecodeCursor(cursor string) (*time.Time, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, "", ErrInvalidCursor
+	}
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Key == "" {
+		return nil, "", ErrInvalidCursor
+	}
+	return c.Time, c.Key, nil
+}
+
+// setTotal 记录总数和总页数
+func (m *PageMeta) setTotal(total int64) {
+	totalPages := int((total + int64(m.PageSize) - 1) / int64(m.PageSize))
+	m.Total = &total
+	m.TotalPages = &totalPages
+}
+
+// OffsetPageMeta 由已知总数生成页码分页信息，用于不经过 Paginate 查询的数据
+func OffsetPageMeta(page, limit int, total int64) PageMeta {
+	meta := PageMeta{Mode: PageModeOffset, Page: max(page, 1), PageSize: max(limit, 1)}
+	meta.setTotal(total)
+	meta.HasMore = int64(meta.Page*meta.PageSize) < total
+	return meta
+}
+
+// Paginate 按分页请求查询数据库
+//
+// 功能说明：
+// 1. 页码分页使用 OFFSET/LIMIT，游标分页使用 (排序列, 主键) 键集条件，都在SQL中完成
+// 2. 多查询一条判断是否还有下一页，游标分页时由最后一条记录生成下一页游标
+// 3. WithTotal 为 true 时额外执行 COUNT 统计总数
+// 4. dest 为模型切片指针，排序列必须是模型中非空的时间字段
+func Paginate(query *gorm.DB, req PageRequest, order PageOrder, dest interface{}) (PageMeta, error) {
+	meta := PageMeta{Mode: req.Mode, PageSize: max(req.Limit, 1)}
+	if meta.Mode != PageModeCursor {
+		meta.Mode = PageModeOffset
+		meta.Page = max(req.Page, 1)
+	}
+
+	stmt := &gorm.Statement{DB: query}
+	if err := stmt.Parse(dest); err != nil {
+		return meta, err
+	}
+	keyField := stmt.Schema.PrioritizedPrimaryField
+	if keyField == nil {
+		return meta, fmt.Errorf("%s 没有主键，无法分页", stmt.Schema.Name)
+	}
+	var timeField *schema.Field
+	if order.Column != "" {
+		if timeField = stmt.Schema.LookUpField(order.Column); timeField == nil {
+			return meta, fmt.Errorf("未知的排序列: %s", order.Column)
+		}
+	}
+
+	if req.WithTotal {
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return meta, err
+		}
+		meta.setTotal(total)
+	}
+
+	timeColumn := clause.Column{}
+	keyColumn := clause.Column{Name: keyField.DBName}
+	find := query.Session(&gorm.Session{})
+	if timeField != nil {
+		timeColumn.Name = timeField.DBName
+		find = find.Order(clause.OrderByColumn{Column: timeColumn, Desc: !order.Asc})
+	}
+	find = find.Order(clause.OrderByColumn{Column: keyColumn, Desc: !order.Asc})
+
+	if meta.Mode == PageModeCursor {
+		if req.Cursor != "" {
+			cursorTime, cursorKey, err := DecodeCursor(req.Cursor)
+			if err != nil {
+				return meta, err
+			}
+			key, err := cursorKeyValue(keyField, cursorKey)
+			if err != nil {
+				return meta, err
+			}
+			op := "<"
+			if order.Asc {
+				op = ">"
+			}
+			if timeField == nil {
+				find = find.Where(clause.Expr{SQL: "? " + op + " ?", Vars: []interface{}{keyColumn, key}})
+			} else {
+				if cursorTime == nil {
+					return meta, ErrInvalidCursor
+				}
+				find = find.Where(clause.Expr{
+					SQL:  "(? " + op + " ? OR (? = ? AND ? " + op + " ?))",
+					Vars: []interface{}{timeColumn, *cursorTime, timeColumn, *cursorTime, keyColumn, key},
+				})
+			}
+		}
+	} else {
+		find = find.Offset((meta.Page - 1) * meta.PageSize)
+	}
+
+	if err := find.Limit(meta.PageSize + 1).Find(dest).Error; err != nil {
+		return meta, err
+	}
+
+	rows := reflect.ValueOf(dest).Elem()
+	if rows.Len() <= meta.PageSize {
+		return meta, nil
+	}
+	rows.Set(rows.Slice(0, meta.PageSize))
+	meta.HasMore = true
+	if meta.Mode != PageModeCursor {
+		return meta, nil
+	}
+
+	last := rows.Index(meta.PageSize - 1)
+	if last.Kind() == reflect.Ptr {
+		last = last.Elem()
+	}
+	key, _ := keyField.ValueOf(query.Statement.Context, last)
+	var cursorTime *time.Time
+	if timeField != nil {
+		value, _ := timeField.ValueOf(query.Statement.Context, last)
+		switch t := value.(type) {
+		case time.Time:
+			cursorTime = &t
+		case *time.Time:
+			cursorTime = t
+		}
+		if cursorTime == nil {
+			return meta, fmt.Errorf("排序列 %s 为空，无法生成游标", order.Column)
+		}
+	}
+	meta.Cursor = EncodeCursor(cursorTime, fmt.Sprint(key))
+	return meta, nil
+}
+
+// cursorKeyValue 按主键类型转换游标中的主键
+func cursorKeyValue(field *schema.Field, key string) (interface{}, error) {
+	switch field.DataType {
+	case schema.Int, schema.Uint:
+		id, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return id, nil
+	default:
+		return key, nil
+	}
+}
+
+// PageSlice 对已在内存中按(时间, 键)降序排列的数据分页，返回当前页在切片中的范围
+// 用于不在数据库中的数据（如内存中的告警），key 返回第i条数据的时间和键
+func PageSlice(n int, req PageRequest, key func(i int) (time.Time, string)) (start, end int, meta PageMeta, err error) {
+	meta = PageMeta{Mode: req.Mode, PageSize: max(req.Limit, 1)}
+	if req.WithTotal {
+		meta.setTotal(int64(n))
+	}
+
+	if meta.Mode == PageModeCursor {
+		if req.Cursor != "" {
+			cursorTime, cursorKey, err := DecodeCursor(req.Cursor)
+			if err != nil || cursorTime == nil {
+				return 0, 0, meta, ErrInvalidCursor
+			}
+			// 跳过不早于游标的数据
+			for start < n {
+				t, k := key(start)
+				if t.Before(*cursorTime) || (t.Equal(*cursorTime) && k < cursorKey) {
+					break
+				}
+				start++
+			}
+		}
+	} else {
+		meta.Mode = PageModeOffset
+		meta.Page = max(req.Page, 1)
+		start = min((meta.Page-1)*meta.PageSize, n)
+	}
+
+	end = min(start+meta.PageSize, n)
+	meta.HasMore = end < n
+	if meta.HasMore && meta.Mode == PageModeCursor {
+		t, k := key(end - 1)
+		meta.Cursor = EncodeCursor(&t, k)
+	}
+	return start, end, meta, nil
+}
@@ -0,0 +1,491 @@
+package Utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 列表查询字段类型
+const (
+	QueryString = "string"
+	QueryInt    = "int"
+	QueryFloat  = "float"
+	QueryBool   = "bool"
+	QueryTime   = "time"
+)
+
+// 筛选操作符
+const (
+	FilterEq   = "eq"
+	FilterNe   = "ne"
+	FilterGt   = "gt"
+	FilterGte  = "gte"
+	FilterLt   = "lt"
+	FilterLte  = "lte"
+	FilterLike = "like" // 包含，不区分大小写
+	FilterIn   = "in"   // 逗号分隔的多个值
+	FilterNull = "null" // true 为空，false 不为空
+)
+
+const (
+	maxQueryFilters  = 20  // 单次请求最多的筛选条件
+	maxQuerySorts    = 3   // 最多的排序字段
+	maxFilterInItems = 100 // in 操作最多的值
+)
+
+// ErrInvalidQuery 列表查询参数无效
+var ErrInvalidQuery = errors.New("无效的查询参数")
+
+// filterParamPattern 匹配 filter[field] 和 filter[field][op]
+var filterParamPattern = regexp.MustCompile(`^filter\[([A-Za-z0-9_]+)\](?:\[([a-z]+)\])?$`)
+
+// QueryField 列表接口对外开放的字段
+type QueryField struct {
+	Column string // 数据库列名，内存中的数据不需要
+	Type   string // 字段类型，决定可用的操作符和值的解析方式
+	Filter bool   // 允许筛选
+	Sort   bool   // 允许排序
+}
+
+// QuerySpec 列表接口的查询白名单
+// Fields 的键为对外的字段名（与JSON字段名一致），fields 参数只能选择其中的字段
+type QuerySpec struct {
+	Fields      map[string]QueryField
+	DefaultSort string // 未传 sort 时的排序，如 "-created_at"
+}
+
+// QueryFilter 一个筛选条件
+type QueryFilter struct {
+	Field string
+	Op    string
+	Value interface{} // 已按字段类型解析，in 操作为切片
+}
+
+// QuerySort 一个排序字段
+type QuerySort struct {
+	Field string
+	Desc  bool
+}
+
+// ListQuery 解析后的列表查询
+type ListQuery struct {
+	Filters []QueryFilter
+	Sorts   []QuerySort
+	Fields  []string // 为空时返回全部字段
+	spec    QuerySpec
+}
+
+// queryError 包装为 ErrInvalidQuery
+func queryError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidQuery, fmt.Sprintf(format, args...))
+}
+
+// ParseListQuery 解析列表查询参数
+//
+// 功能说明：
+// 1. filter[field]=value 或 filter[field][op]=value，op 为 eq/ne/gt/gte/lt/lte/like/in/null
+// 2. sort=-created_at,id，字段前加 - 表示降序，最多3个字段
+// 3. fields=id,event_type 只返回指定字段
+// 4. 字段、操作符和值都按 spec 白名单校验，不合法时返回 ErrInvalidQuery，不会拼接到SQL中
+func ParseListQuery(values url.Values, spec QuerySpec) (*ListQuery, error) {
+	q := &ListQuery{spec: spec}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !strings.HasPrefix(key, "filter[") {
+			continue
+		}
+		match := filterParamPattern.FindStringSubmatch(key)
+		if match == nil {
+			return nil, queryError("筛选参数格式错误 %s", key)
+		}
+		name, op := match[1], match[2]
+		if op == "" {
+			op = FilterEq
+		}
+		field, ok := spec.Fields[name]
+		if !ok || !field.Filter {
+			return nil, queryError("不支持筛选字段 %s", name)
+		}
+		for _, raw := range values[key] {
+			value, err := parseFilterValue(field, op, raw)
+			if err != nil {
+				return nil, queryError("%s[%s]: %v", name, op, err)
+			}
+			q.Filters = append(q.Filters, QueryFilter{Field: name, Op: op, Value: value})
+		}
+	}
+	if len(q.Filters) > maxQueryFilters {
+		return nil, queryError("筛选条件不能超过%d个", maxQueryFilters)
+	}
+
+	sortParam := values.Get("sort")
+	if sortParam == "" {
+		sortParam = spec.DefaultSort
+	}
+	for _, item := range strings.Split(sortParam, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		s := QuerySort{Field: strings.TrimPrefix(item, "-"), Desc: strings.HasPrefix(item, "-")}
+		if field, ok := spec.Fields[s.Field]; !ok || !field.Sort {
+			return nil, queryError("不支持按 %s 排序", s.Field)
+		}
+		q.Sorts = append(q.Sorts, s)
+	}
+	if len(q.Sorts) > maxQuerySorts {
+		return nil, queryError("排序字段不能超过%d个", maxQuerySorts)
+	}
+
+	if fields := values.Get("fields"); fields != "" {
+		for _, name := range strings.Split(fields, ",") {
+			name = strings.TrimSpace(name)
+			if _, ok := spec.Fields[name]; !ok {
+				return nil, queryError("不支持返回字段 %s", name)
+			}
+			q.Fields = append(q.Fields, name)
+		}
+	}
+	return q, nil
+}
+
+// parseFilterValue 按字段类型解析筛选值并检查操作符
+func parseFilterValue(field QueryField, op, raw string) (interface{}, error) {
+	switch op {
+	case FilterEq, FilterNe:
+		return parseQueryValue(field.Type, raw)
+	case FilterGt, FilterGte, FilterLt, FilterLte:
+		if field.Type == QueryString || field.Type == QueryBool {
+			return nil, fmt.Errorf("字段类型 %s 不支持比较", field.Type)
+		}
+		return parseQueryValue(field.Type, raw)
+	case FilterLike:
+		if field.Type != QueryString {
+			return nil, errors.New("只有字符串字段支持 like")
+		}
+		return raw, nil
+	case FilterIn:
+		items := strings.Split(raw, ",")
+		if len(items) > maxFilterInItems {
+			return nil, fmt.Errorf("in 最多%d个值", maxFilterInItems)
+		}
+		values := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			value, err := parseQueryValue(field.Type, strings.TrimSpace(item))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	case FilterNull:
+		isNull, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, errors.New("null 的值必须是 true 或 false")
+		}
+		return isNull, nil
+	default:
+		return nil, fmt.Errorf("不支持的操作符 %s", op)
+	}
+}
+
+// parseQueryValue 按字段类型解析值，时间支持RFC3339和2006-01-02
+func parseQueryValue(fieldType, raw string) (interface{}, error) {
+	switch fieldType {
+	case QueryInt:
+		return strconv.ParseInt(raw, 10, 64)
+	case QueryFloat:
+		return strconv.ParseFloat(raw, 64)
+	case QueryBool:
+		return strconv.ParseBool(raw)
+	case QueryTime:
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return t, nil
+		}
+		t, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return nil, fmt.Errorf("无效的时间 %s", raw)
+		}
+		return t, nil
+	default:
+		return raw, nil
+	}
+}
+
+// escapeLike 转义 LIKE 中的通配符，配合 ESCAPE '!' 使用
+func escapeLike(value string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(value)
+}
+
+// Apply 将筛选条件和返回字段应用到查询，返回分页使用的排序
+//
+// 功能说明：
+// 1. 筛选条件使用参数绑定，列名来自白名单并加引号
+// 2. 指定 fields 时只查询这些列，以及主键和排序列
+// 3. 按单个时间字段或id排序（可再按id排序）时交给分页处理，支持游标分页
+// 4. 其他排序先按指定字段排序、最后按主键排序，只支持页码分页
+func (q *ListQuery) Apply(query *gorm.DB, req PageRequest) (*gorm.DB, PageOrder, error) {
+	for _, filter := range q.Filters {
+		column := clause.Column{Name: q.spec.Fields[filter.Field].Column}
+		switch filter.Op {
+		case FilterEq:
+			query = query.Where(clause.Expr{SQL: "? = ?", Vars: []interface{}{column, filter.Value}})
+		case FilterNe:
+			query = query.Where(clause.Expr{SQL: "? <> ?", Vars: []interface{}{column, filter.Value}})
+		case FilterGt:
+			query = query.Where(clause.Expr{SQL: "? > ?", Vars: []interface{}{column, filter.Value}})
+		case FilterGte:
+			query = query.Where(clause.Expr{SQL: "? >= ?", Vars: []interface{}{column, filter.Value}})
+		case FilterLt:
+			query = query.Where(clause.Expr{SQL: "? < ?", Vars: []interface{}{column, filter.Value}})
+		case FilterLte:
+			query = query.Where(clause.Expr{SQL: "? <= ?", Vars: []interface{}{column, filter.Value}})
+		case FilterLike:
+			pattern := "%" + escapeLike(strings.ToLower(filter.Value.(string))) + "%"
+			query = query.Where(clause.Expr{SQL: "LOWER(?) LIKE ? ESCAPE '!'", Vars: []interface{}{column, pattern}})
+		case FilterIn:
+			query = query.Where(clause.Expr{SQL: "? IN ?", Vars: []interface{}{column, filter.Value}})
+		case FilterNull:
+			if filter.Value.(bool) {
+				query = query.Where(clause.Expr{SQL: "? IS NULL", Vars: []interface{}{column}})
+			} else {
+				query = query.Where(clause.Expr{SQL: "? IS NOT NULL", Vars: []interface{}{column}})
+			}
+		}
+	}
+
+	order, keyset := q.pageOrder()
+	if !keyset {
+		if req.Mode == PageModeCursor {
+			return query, order, queryError("游标分页只支持按单个时间字段或id排序")
+		}
+		for _, s := range q.Sorts {
+			query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: q.spec.Fields[s.Field].Column}, Desc: s.Desc})
+		}
+	}
+
+	if len(q.Fields) > 0 {
+		columns := []string{"id"}
+		for _, name := range q.Fields {
+			columns = appendUnique(columns, q.spec.Fields[name].Column)
+		}
+		for _, s := range q.Sorts {
+			columns = appendUnique(columns, q.spec.Fields[s.Field].Column)
+		}
+		query = query.Select(columns)
+	}
+	return query, order, nil
+}
+
+// pageOrder 排序能否用 (时间列, 主键) 的键集分页表示
+func (q *ListQuery) pageOrder() (PageOrder, bool) {
+	if len(q.Sorts) == 0 {
+		return PageOrder{}, true
+	}
+	first := q.Sorts[0]
+	field := q.spec.Fields[first.Field]
+	order := PageOrder{Asc: !first.Desc}
+	if field.Type == QueryTime {
+		order.Column = field.Column
+	} else if field.Column != "id" {
+		return PageOrder{Asc: !q.Sorts[len(q.Sorts)-1].Desc}, false
+	}
+
+	switch len(q.Sorts) {
+	case 1:
+		return order, true
+	case 2:
+		second := q.Sorts[1]
+		if order.Column != "" && q.spec.Fields[second.Field].Column == "id" && second.Desc == first.Desc {
+			return order, true
+		}
+	}
+	return PageOrder{Asc: !q.Sorts[len(q.Sorts)-1].Desc}, false
+}
+
+func appendUnique(items []string, item string) []string {
+	for _, existing := range items {
+		if existing == item {
+			return items
+		}
+	}
+	return append(items, item)
+}
+
+// Project 只保留 fields 指定的字段，未指定时原样返回
+// data 为切片，按JSON字段名投影为 []map[string]interface{}
+func (q *ListQuery) Project(data interface{}) (interface{}, error) {
+	if len(q.Fields) == 0 {
+		return data, nil
+	}
+	rows, err := toQueryRecords(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		for key := range row {
+			if !containsField(q.Fields, key) {
+				delete(row, key)
+			}
+		}
+	}
+	return rows, nil
+}
+
+func containsField(fields []string, name string) bool {
+	for _, field := range fields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// toQueryRecords 将切片转换为按JSON字段名索引的记录
+func toQueryRecords(data interface{}) ([]map[string]interface{}, error) {
+	if v := reflect.ValueOf(data); v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("只能处理切片，实际为 %T", data)
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]map[string]interface{}, 0)
+	decoder := json.NewDecoder(strings.NewReader(string(raw)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Match 判断内存中的记录是否满足全部筛选条件，用于不在数据库中的数据
+// record 为按JSON字段名索引的记录，比较规则与 Apply 生成的SQL一致
+func (q *ListQuery) Match(record map[string]interface{}) bool {
+	for _, filter := range q.Filters {
+		value, exists := record[filter.Field]
+		if filter.Op == FilterNull {
+			if (!exists || value == nil) != filter.Value.(bool) {
+				return false
+			}
+			continue
+		}
+		if !exists || value == nil {
+			return false
+		}
+
+		fieldType := q.spec.Fields[filter.Field].Type
+		switch filter.Op {
+		case FilterLike:
+			if !strings.Contains(strings.ToLower(fmt.Sprint(value)), strings.ToLower(filter.Value.(string))) {
+				return false
+			}
+		case FilterIn:
+			found := false
+			for _, candidate := range filter.Value.([]interface{}) {
+				if cmp, ok := compareQueryValue(fieldType, value, candidate); ok && cmp == 0 {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		default:
+			cmp, ok := compareQueryValue(fieldType, value, filter.Value)
+			if !ok {
+				return false
+			}
+			switch filter.Op {
+			case FilterEq:
+				ok = cmp == 0
+			case FilterNe:
+				ok = cmp != 0
+			case FilterGt:
+				ok = cmp > 0
+			case FilterGte:
+				ok = cmp >= 0
+			case FilterLt:
+				ok = cmp < 0
+			case FilterLte:
+				ok = cmp <= 0
+			}
+			if !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// compareQueryValue 比较记录中的值和筛选值，返回 -1/0/1，无法比较时返回 false
+func compareQueryValue(fieldType string, value, target interface{}) (int, bool) {
+	switch fieldType {
+	case QueryInt, QueryFloat:
+		number, err := strconv.ParseFloat(fmt.Sprint(value), 64)
+		if err != nil {
+			return 0, false
+		}
+		var expected float64
+		switch t := target.(type) {
+		case int64:
+			expected = float64(t)
+		case float64:
+			expected = t
+		default:
+			return 0, false
+		}
+		switch {
+		case number < expected:
+			return -1, true
+		case number > expected:
+			return 1, true
+		}
+		return 0, true
+	case QueryBool:
+		b, ok := value.(bool)
+		if !ok || b != target.(bool) {
+			return 1, ok
+		}
+		return 0, true
+	case QueryTime:
+		t, err := time.Parse(time.RFC3339Nano, fmt.Sprint(value))
+		if err != nil {
+			return 0, false
+		}
+		return t.Compare(target.(time.Time)), true
+	default:
+		return strings.Compare(fmt.Sprint(value), target.(string)), true
+	}
+}
+
+// FilterRecords 在内存中按筛选条件过滤切片，返回满足条件元素的下标
+func (q *ListQuery) FilterRecords(data interface{}) ([]int, error) {
+	rows, err := toQueryRecords(data)
+	if err != nil {
+		return nil, err
+	}
+	indexes := make([]int, 0, len(rows))
+	for i, row := range rows {
+		if q.Match(row) {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes, nil
+}
@@ -0,0 +1,87 @@
+package Utils
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// 请求ID和关联ID的请求头
+// 请求ID标识单个请求，关联ID标识跨系统的一组请求（未传入时等于请求ID）
+const (
+	RequestIDHeader     = "X-Request-ID"
+	CorrelationIDHeader = "X-Correlation-ID"
+)
+
+// 请求ID和关联ID在 gin.Context 和请求上下文中的键
+// 使用字符串键，gin.Context 的 Value 从 c.Keys 读取，两种上下文使用相同的键
+const (
+	RequestIDKey     = "request_id"
+	CorrelationIDKey = "correlation_id"
+)
+
+// maxRequestIDLength 接受的请求ID最大长度
+const maxRequestIDLength = 128
+
+// NewRequestID 生成请求ID
+func NewRequestID() string {
+	return uuid.NewString()
+}
+
+// ValidRequestID 检查客户端传入的请求ID，只接受不超过128个字符的字母、数字和 . _ : -
+// 拒绝其他字符，避免日志注入和响应头注入
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		ch := id[i]
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9':
+		case ch == '.' || ch == '_' || ch == ':' || ch == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// WithRequestIDs 把请求ID和关联ID保存到 ctx 中，为空的ID不保存
+func WithRequestIDs(ctx context.Context, requestID, correlationID string) context.Context {
+	if requestID != "" {
+		ctx = context.WithValue(ctx, RequestIDKey, requestID)
+	}
+	if correlationID != "" {
+		ctx = context.WithValue(ctx, CorrelationIDKey, correlationID)
+	}
+	return ctx
+}
+
+// RequestIDFromContext 获取 ctx 中的请求ID，不存在时返回空字符串
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(RequestIDKey).(string)
+	return requestID
+}
+
+// CorrelationIDFromContext 获取 ctx 中的关联ID，不存在时返回空字符串
+func CorrelationIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	correlationID, _ := ctx.Value(CorrelationIDKey).(string)
+	return correlationID
+}
+
+// SetRequestIDHeaders 在出站请求上设置请求ID和关联ID，为空或请求中已设置的不覆盖
+func SetRequestIDHeaders(header http.Header, requestID, correlationID string) {
+	if requestID != "" && header.Get(RequestIDHeader) == "" {
+		header.Set(RequestIDHeader, requestID)
+	}
+	if correlationID != "" && header.Get(CorrelationIDHeader) == "" {
+		header.Set(CorrelationIDHeader, correlationID)
+	}
+}
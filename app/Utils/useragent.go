@@ -0,0 +1,54 @@
+package Utils
+
+import "strings"
+
+// UserAgentInfo 从 User-Agent 解析出的浏览器和操作系统
+type UserAgentInfo struct {
+	Browser string `json:"browser"`
+	OS      string `json:"os"`
+}
+
+// String 设备描述，如 "Chrome on Windows"，无法识别的部分为 Unknown
+func (u UserAgentInfo) String() string {
+	return u.Browser + " on " + u.OS
+}
+
+// ParseUserAgent 解析 User-Agent 中的浏览器和操作系统
+// 只识别主流浏览器和系统，不区分版本，用于登录记录展示和新设备判断
+func ParseUserAgent(userAgent string) UserAgentInfo {
+	ua := strings.ToLower(userAgent)
+	info := UserAgentInfo{Browser: "Unknown", OS: "Unknown"}
+
+	// Edge、Opera 的 UA 同时包含 Chrome，Chrome 的 UA 同时包含 Safari，按从具体到通用的顺序匹配
+	switch {
+	case strings.Contains(ua, "edg/") || strings.Contains(ua, "edge/"):
+		info.Browser = "Edge"
+	case strings.Contains(ua, "opr/") || strings.Contains(ua, "opera"):
+		info.Browser = "Opera"
+	case strings.Contains(ua, "firefox/") || strings.Contains(ua, "fxios/"):
+		info.Browser = "Firefox"
+	case strings.Contains(ua, "chrome/") || strings.Contains(ua, "crios/"):
+		info.Browser = "Chrome"
+	case strings.Contains(ua, "safari/"):
+		info.Browser = "Safari"
+	case strings.Contains(ua, "curl/"):
+		info.Browser = "curl"
+	}
+
+	// Android 的 UA 同时包含 Linux，iOS 的 UA 同时包含 Mac OS X
+	switch {
+	case strings.Contains(ua, "windows"):
+		info.OS = "Windows"
+	case strings.Contains(ua, "android"):
+		info.OS = "Android"
+	case strings.Contains(ua, "iphone") || strings.Contains(ua, "ipad") || strings.Contains(ua, "ipod"):
+		info.OS = "iOS"
+	case strings.Contains(ua, "mac os x") || strings.Contains(ua, "macintosh"):
+		info.OS = "macOS"
+	case strings.Contains(ua, "cros "):
+		info.OS = "ChromeOS"
+	case strings.Contains(ua, "linux"):
+		info.OS = "Linux"
+	}
+	return info
+}
@@ -2,12 +2,17 @@ package app
 
 import (
 	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Dashboard"
 	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Grpc"
 	"cloud-platform-api/app/Http/Routes"
+	"cloud-platform-api/app/I18n"
 	"cloud-platform-api/app/Services"
 	"cloud-platform-api/app/Storage"
 	"cloud-platform-api/bootstrap"
 	"context"
+	"crypto/tls"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -30,19 +35,20 @@ type App struct {
 // 2. 验证配置的有效性和完整性
 // 3. 初始化存储管理器（创建storage目录结构）
 // 4. 初始化Redis服务和缓存服务（支持降级到内存缓存）
-// 5. 使用StorageManager初始化数据库（启用SQL日志记录）
-// 6. 创建Gin路由引擎和中间件链
-// 7. 注册所有HTTP路由和API端点
-// 8. 执行数据库自动迁移（表结构同步）
-// 9. 预热缓存（如果Redis可用）
-// 10. 记录应用启动成功的详细日志信息
-// 11. 返回完整的应用实例供启动使用
-// 12. 支持优雅关闭和资源清理
+// 5. 等待数据库、Redis等依赖就绪（指数退避重试，可选降级模式启动）
+// 6. 使用StorageManager初始化数据库（启用SQL日志记录）
+// 7. 创建Gin路由引擎和中间件链
+// 8. 注册所有HTTP路由和API端点
+// 9. 执行数据库自动迁移（表结构同步）
+// 10. 预热缓存（如果Redis可用）
+// 11. 记录应用启动成功的详细日志信息
+// 12. 返回完整的应用实例供启动使用
+// 13. 支持优雅关闭和资源清理
 //
 // 错误处理：
 // - 配置验证失败时立即退出
 // - Redis连接失败时降级到内存缓存
-// - 数据库连接失败时重试3次后退出
+// - 数据库在等待时间内未就绪时退出，启用降级模式时继续启动并暂停API
 // - 缓存预热失败时记录警告但不影响启动
 //
 // 资源管理：
@@ -65,6 +71,11 @@ func NewApp() *App {
 		log.Fatal("配置验证失败:", err)
 	}
 
+	// 初始化国际化（内置语言包 + 配置目录中的语言包）
+	translator := I18n.Init(&Config.GetConfig().I18n)
+	I18n.SetUserPreferenceResolver(Services.NewUserService().GetPreferredLocale)
+	log.Printf("国际化初始化完成，默认语言: %s, 已加载语言: %v", translator.DefaultLocale(), translator.Locales())
+
 	// 初始化存储管理器
 	storageConfig := Config.GetStorageConfig()
 	storageManager := Storage.NewStorageManager(storageConfig)
@@ -89,28 +100,67 @@ func NewApp() *App {
 			Password: redisConfig.Password,
 			DB:       redisConfig.Database,
 		})
-
-		// 测试Redis连接
-		if err := redisService.Ping(); err != nil {
-			log.Printf("警告: Redis连接失败: %v", err)
-			redisService = nil
-		} else {
-			log.Println("✅ Redis服务连接成功")
-		}
 	}
 
 	// 初始化缓存服务
 	cacheService := Services.NewCacheService(storageManager)
 
-	// 预热缓存（如果Redis可用）
+	// 等待数据库、Redis等依赖就绪
+	// 关键依赖超时未就绪时启动失败，启用降级模式时继续启动并在后台重试
+	startup := Services.NewStartupOrchestrator(Config.GetStartupConfig())
+	startup.Register(Services.StartupDependency{
+		Name:          "database",
+		Check:         Database.Ping,
+		Features:      []string{"api"},
+		RoutePrefixes: []string{"/api"},
+		OnReady:       Database.AutoMigrate,
+	})
 	if redisService != nil {
+		startup.Register(Services.StartupDependency{
+			Name:     "redis",
+			Check:    redisService.PingContext,
+			Features: []string{"redis_cache"},
+			// 降级启动后Redis恢复时从内存缓存切换到Redis并预热缓存
+			OnReady: func() {
+				cacheService.UseRedis(redisService)
+				log.Println("✅ Redis服务已恢复，切换到Redis缓存")
+				if err := cacheService.WarmCache(); err != nil {
+					log.Printf("警告: 缓存预热失败: %v", err)
+				}
+			},
+		})
+	}
+	if err := startup.Wait(context.Background()); err != nil {
+		log.Fatal("依赖等待失败:", err)
+	}
+	Services.SetDefaultStartupOrchestrator(startup)
+
+	// 测试Redis连接，未就绪时先使用内存缓存，Redis恢复后由 OnReady 切换
+	redisReady := redisService != nil && startup.DependencyReady("redis")
+	if redisService != nil {
+		if !redisReady {
+			log.Printf("警告: Redis连接失败，使用内存缓存，恢复后自动切换到Redis")
+		} else {
+			log.Println("✅ Redis服务连接成功")
+		}
+	}
+
+	// Redis可用时缓存服务使用Redis后端并预热缓存
+	if redisReady {
+		cacheService.UseRedis(redisService)
 		if err := cacheService.WarmCache(); err != nil {
 			log.Printf("警告: 缓存预热失败: %v", err)
 		}
 	}
 
 	// 使用LogManagerService初始化数据库（启用SQL日志）
-	Database.InitDBWithLogManager(logManager)
+	// 数据库未就绪（降级模式）时不测试连接，数据库恢复后自动执行迁移
+	databaseReady := startup.DependencyReady("database")
+	if databaseReady {
+		Database.InitDBWithLogManager(logManager)
+	} else {
+		Database.InitDBDeferredWithLogManager(logManager)
+	}
 
 	// 初始化应用
 	app := &App{
@@ -121,10 +171,15 @@ func NewApp() *App {
 	}
 
 	// 注册路由
-	Routes.RegisterRoutes(app.Router.Engine, storageManager, logManager)
+	background := Routes.RegisterRoutes(app.Router.Engine, storageManager, logManager)
 
 	// 自动迁移数据库表
-	Database.AutoMigrate()
+	if databaseReady {
+		Database.AutoMigrate()
+	}
+
+	// 启动路由注册时创建的后台服务（定时任务、事件投递、gRPC服务等）
+	background.Start()
 
 	// 记录应用启动日志到对应的日志类型中
 	startupCtx := context.Background()
@@ -149,10 +204,10 @@ func NewApp() *App {
 
 	// 记录缓存信息到业务日志
 	logManager.LogBusiness(startupCtx, "cache", "startup", "缓存服务初始化", map[string]interface{}{
-		"redis_enabled": redisService != nil,
+		"redis_enabled": redisReady,
 		"cache_enabled": false, // 暂时禁用缓存
 		"cache_type": func() string {
-			if redisService != nil {
+			if redisReady {
 				return "redis"
 			}
 			return "memory"
@@ -171,16 +226,18 @@ func NewApp() *App {
 // 4. 收到关闭信号时优雅关闭服务器
 // 5. 清理资源（数据库连接、日志管理器等）
 // 6. 记录服务器启动和关闭日志
+// 7. 启用TLS时提供HTTPS和HTTP/2，证书文件变化后自动重新加载，可选ACME自动申请证书和HTTP到HTTPS的重定向
+// 8. 服务器管理器统一管理API、管理、指标和仪表板端口，各端口使用独立的路由、TLS和访问控制
 //
 // 启动流程：
 // 1. 创建HTTP服务器，配置地址和处理器
-// 2. 在goroutine中启动服务器（不阻塞主流程）
+// 2. 监听所有端口后在goroutine中提供服务（不阻塞主流程），任一端口被占用时启动失败
 // 3. 监听系统信号（SIGINT、SIGTERM）
 // 4. 收到信号后启动优雅关闭流程
 //
 // 优雅关闭流程：
 // 1. 停止接受新请求（Shutdown）
-// 2. 等待正在处理的请求完成（最多30秒）
+// 2. 等待正在处理的请求完成（最多 LISTENER_SHUTDOWN_TIMEOUT，默认30秒）
 // 3. 关闭数据库连接
 // 4. 关闭日志管理器
 // 5. 记录关闭日志
@@ -191,8 +248,8 @@ func NewApp() *App {
 // - 收到信号后启动优雅关闭，不立即退出
 //
 // 超时处理：
-// - 优雅关闭超时时间：30秒
-// - 如果30秒内无法关闭，强制关闭
+// - 优雅关闭超时时间：LISTENER_SHUTDOWN_TIMEOUT（默认30秒），所有端口共用
+// - 超时后强制关闭未完成的连接
 // - 这确保服务器不会无限期等待
 //
 // 资源清理：
@@ -212,24 +269,108 @@ func NewApp() *App {
 // - 超时时间应该根据实际情况调整
 // - 资源清理应该按顺序进行，避免依赖问题
 func (app *App) Run() error {
+	// 服务器管理器统一启动和关闭API、HTTP重定向、管理、指标和仪表板端口
+	// 各端口的处理器在进程内共用Gin引擎，按路由前缀划分可访问的路由
+	servers := Services.NewServerManager()
+	listeners := app.Config.Listeners
+	addrs := Config.ListenerAddrs(app.Config)
+
 	// 创建HTTP服务器
 	// 配置服务器地址（端口）和请求处理器（Gin引擎）
+	// 管理端口和指标端口启用后，对应的路由不再通过API端口访问
+	var apiHandler http.Handler = app.Router.Engine
+	if listeners.AdminEnabled {
+		apiHandler = Services.RouteFilter(apiHandler, listeners.AdminPrefixList(), false)
+	}
+	if listeners.MetricsEnabled {
+		apiHandler = Services.RouteFilter(apiHandler, []string{"/metrics"}, false)
+	}
 	srv := &http.Server{
-		Addr:    ":" + app.Config.Server.Port, // 监听地址（例如：:8080）
-		Handler: app.Router.Engine,             // 请求处理器（Gin引擎，包含所有路由和中间件）
+		Addr:    addrs[Config.ListenerAPI], // 监听地址（例如：:8080）
+		Handler: apiHandler,                // 请求处理器（Gin引擎，包含所有路由和中间件）
 	}
 
-	// 在goroutine中启动服务器
-	// 这样主流程可以继续执行，等待关闭信号
-	go func() {
-		log.Printf("Server starting on port %s", app.Config.Server.Port)
-		// 启动HTTP服务器，开始监听请求
-		// ListenAndServe会阻塞，直到服务器关闭
-		// http.ErrServerClosed是正常关闭，不应该报错
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server error: %v", err)
+	// 启用TLS时配置证书（文件自动重新加载或ACME自动申请）、协议版本、加密套件和HTTP/2
+	// HTTP端口把请求重定向到HTTPS，自动申请证书时同时处理HTTP-01验证
+	var serverTLS *Services.ServerTLS
+	var reloaders []*Services.CertificateReloader
+	if tlsConfig := app.Config.TLS; tlsConfig.Enabled {
+		var err error
+		serverTLS, err = Services.NewServerTLS(&tlsConfig, app.Config.Server.Port)
+		if err != nil {
+			return fmt.Errorf("TLS配置失败: %v", err)
 		}
-	}()
+		srv.TLSConfig = serverTLS.TLSConfig
+		if !serverTLS.HTTP2 {
+			// TLSNextProto 非nil时 net/http 不启用HTTP/2
+			srv.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+		}
+		if serverTLS.Reloader != nil {
+			reloaders = append(reloaders, serverTLS.Reloader)
+		}
+	}
+	servers.Add(Config.ListenerAPI, srv)
+	if serverTLS != nil && serverTLS.HTTPHandler != nil {
+		servers.Add(Config.ListenerRedirect, &http.Server{
+			Addr:              addrs[Config.ListenerRedirect],
+			Handler:           serverTLS.HTTPHandler,
+			ReadHeaderTimeout: 10 * time.Second,
+		})
+	}
+
+	// 内部端口：管理端口只提供管理路由，指标端口只提供 /metrics，仪表板端口提供内嵌的管理仪表板并在进程内转发接口请求
+	type internalListener struct {
+		name     string
+		listener Config.ListenerConfig
+		handler  http.Handler
+	}
+	var internal []internalListener
+	if listeners.AdminEnabled {
+		internal = append(internal, internalListener{Config.ListenerAdmin, listeners.Admin, Services.RouteFilter(app.Router.Engine, listeners.AdminPrefixList(), true)})
+	}
+	if listeners.MetricsEnabled {
+		internal = append(internal, internalListener{Config.ListenerMetrics, listeners.Metrics, Services.RouteFilter(app.Router.Engine, []string{"/metrics"}, true)})
+	}
+	if _, ok := addrs[Config.ListenerDashboard]; ok {
+		// 接口请求转发给API端口的处理器，启用管理端口或指标端口后仪表板端口同样不能访问这些路由
+		dashboardServer := Dashboard.NewServer(apiHandler)
+		internal = append(internal, internalListener{Config.ListenerDashboard, listeners.Dashboard, dashboardServer})
+	}
+	for _, item := range internal {
+		networks, err := item.listener.AllowedNetworks()
+		if err != nil {
+			return fmt.Errorf("%s 端口配置失败: %v", item.name, err)
+		}
+		listenerTLS, reloader, err := Services.ListenerTLSConfig(&item.listener, serverTLS, &app.Config.TLS)
+		if err != nil {
+			return fmt.Errorf("%s 端口TLS配置失败: %v", item.name, err)
+		}
+		if reloader != nil {
+			reloaders = append(reloaders, reloader)
+		}
+		servers.Add(item.name, &http.Server{
+			Addr:              addrs[item.name],
+			Handler:           Services.ListenerGuard(item.handler, networks, item.listener.AuthToken),
+			TLSConfig:         listenerTLS,
+			ReadHeaderTimeout: 10 * time.Second,
+		})
+	}
+
+	// 证书文件变化后自动重新加载
+	for _, reloader := range reloaders {
+		if err := reloader.Watch(); err != nil {
+			log.Printf("TLS证书文件监听失败，证书更新后需要重启: %v", err)
+		}
+	}
+
+	// 先监听所有端口再开始提供服务，任何端口被占用时启动失败
+	if err := servers.Start(); err != nil {
+		for _, reloader := range reloaders {
+			reloader.Close()
+		}
+		return fmt.Errorf("服务器启动失败: %v", err)
+	}
+	log.Printf("Servers started: %v (API TLS: %v)", servers.Names(), serverTLS != nil)
 
 	// 等待中断信号
 	// 创建信号通道，用于接收系统信号
@@ -238,24 +379,56 @@ func (app *App) Run() error {
 	// SIGINT: Ctrl+C
 	// SIGTERM: kill命令
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	// 阻塞等待信号
-	<-quit
+	// 阻塞等待信号，任一端口服务出错时同样关闭所有端口
+	select {
+	case <-quit:
+	case err := <-servers.Errors():
+		log.Printf("Server error, shutting down: %v", err)
+	}
 
 	log.Println("Shutting down server...")
 
 	// 优雅关闭服务器
-	// 创建带超时的上下文，最多等待30秒
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// 创建带超时的上下文，最多等待 LISTENER_SHUTDOWN_TIMEOUT（默认30秒）
+	ctx, cancel := context.WithTimeout(context.Background(), listeners.ShutdownTimeout)
 	defer cancel() // 确保cancel被调用，释放资源
 
-	// 优雅关闭服务器
+	// 并行优雅关闭所有端口
 	// Shutdown会：
 	// 1. 停止接受新请求
 	// 2. 等待正在处理的请求完成
-	// 3. 如果超时，返回错误
-	if err := srv.Shutdown(ctx); err != nil {
-		// 优雅关闭失败，强制关闭
-		log.Fatal("Server forced to shutdown:", err)
+	// 3. 如果超时，强制关闭剩余连接并返回错误
+	if err := servers.Shutdown(ctx); err != nil {
+		log.Printf("Server forced to shutdown: %v", err)
+	}
+
+	// 停止监听证书文件
+	for _, reloader := range reloaders {
+		reloader.Close()
+	}
+
+	// 关闭内部gRPC服务，等待进行中的调用完成
+	if grpcServer := Grpc.DefaultServer(); grpcServer != nil {
+		if err := grpcServer.Stop(ctx); err != nil {
+			log.Printf("Error stopping gRPC server: %v", err)
+		}
+	}
+
+	// 停止降级启动后的依赖重试
+	if startup := Services.DefaultStartupOrchestrator(); startup != nil {
+		startup.Stop()
+	}
+
+	// 停止领域事件分发器，未投递的事件保留在 outbox 表中，下次启动后继续投递
+	if eventBus := Services.DefaultEventBus(); eventBus != nil {
+		eventBus.Stop()
+	}
+
+	// 写入内存中尚未持久化的用量计数
+	if metering := Services.DefaultMeteringService(); metering != nil {
+		if err := metering.Flush(); err != nil {
+			log.Printf("Error flushing usage records: %v", err)
+		}
 	}
 
 	// 关闭数据库连接
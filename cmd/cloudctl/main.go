@@ -0,0 +1,16 @@
+package main
+
+import (
+	"cloud-platform-api/app/Console"
+	"os"
+)
+
+// main cloudctl 运维命令行工具入口
+// 用法示例：
+//
+//	go run ./cmd/cloudctl migrate up
+//	go run ./cmd/cloudctl user create-admin --username admin --email admin@example.com
+//	go run ./cmd/cloudctl logs tail --logger error -f
+func main() {
+	os.Exit(Console.NewApplication(os.Stdout, os.Stderr).Run(os.Args[1:]))
+}
@@ -0,0 +1,177 @@
+package ActivityTimeline
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Testing"
+	"cloud-platform-api/app/Utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func setupDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "activity.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.User{}, &Models.LoginAttempt{}, &Models.AuditLog{},
+		&Models.SecurityEvent{}, &Models.ApiKey{}))
+	previous := Database.DB
+	Database.DB = db
+	t.Cleanup(func() { Database.DB = previous })
+	return db
+}
+
+// seedActivity 为用户写入每种类型的活动，返回按时间倒序的活动类型
+func seedActivity(t *testing.T, db *gorm.DB, user, other *Models.User) []string {
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+
+	require.NoError(t, db.Create(&[]Models.LoginAttempt{
+		{Username: user.Username, IPAddress: "203.0.113.7", Success: true, AttemptTime: at(1), DeviceInfo: "Chrome on Windows", Location: "CN"},
+		{Username: user.Username, IPAddress: "198.51.100.9", Success: false, FailureReason: "invalid credentials", AttemptTime: at(2)},
+		{Username: other.Username, IPAddress: "203.0.113.8", Success: true, AttemptTime: at(3)},
+	}).Error)
+
+	audit := func(entry *Models.AuditLog, minutes int) {
+		entry.CreatedAt = at(minutes)
+		require.NoError(t, db.Create(entry).Error)
+	}
+	audit(Models.NewAuditLog(user.ID, user.Username, "password_change", "user", user.ID).SetDescription("密码修改成功"), 4)
+	audit(Models.NewAuditLog(user.ID, user.Username, "create_api_key", "api_key", 7).SetDescription("创建API密钥"), 5)
+	audit(Models.NewAuditLog(user.ID, user.Username, "update_user", "user", user.ID).SetDescription("更新用户信息"), 6)
+	audit(Models.NewAuditLog(1, "admin", Models.AuditActionImpersonationStart, "user", user.ID).SetDescription("管理员开始模拟登录"), 7)
+	audit(Models.NewAuditLog(user.ID, user.Username, "delete_tag", "tag", 3), 8)
+	audit(Models.NewAuditLog(other.ID, other.Username, "update_user", "user", other.ID), 9)
+
+	require.NoError(t, db.Create(&Models.SecurityEvent{
+		EventType: "brute_force", EventLevel: "high", UserID: &user.ID, Details: "短时间内多次登录失败",
+		Blocked: true, CreatedAt: at(10),
+	}).Error)
+
+	return []string{"security", "security", "profile", "api_key", "password", "login", "login"}
+}
+
+func types(events []Services.ActivityEvent) []string {
+	result := make([]string, 0, len(events))
+	for _, event := range events {
+		result = append(result, event.Type)
+	}
+	return result
+}
+
+func TestTimelineMergesSourcesAndPaginates(t *testing.T) {
+	db := setupDB(t)
+	factory := Testing.NewFactory(t, db)
+	user, other := factory.User(), factory.User()
+	expected := seedActivity(t, db, user, other)
+	service := Services.NewActivityTimelineService(db)
+
+	all, meta, err := service.Timeline(user.ID, Services.ActivityFilter{},
+		Utils.PageRequest{Mode: Utils.PageModeOffset, Page: 1, Limit: 20, WithTotal: true})
+	require.NoError(t, err)
+	assert.Equal(t, expected, types(all))
+	assert.Equal(t, int64(7), *meta.Total)
+	assert.False(t, all[0].Success, "被阻止的安全事件")
+	assert.Equal(t, "Chrome on Windows", all[6].Device)
+
+	// 页码分页
+	page, meta, err := service.Timeline(user.ID, Services.ActivityFilter{},
+		Utils.PageRequest{Mode: Utils.PageModeOffset, Page: 2, Limit: 3, WithTotal: true})
+	require.NoError(t, err)
+	assert.Equal(t, expected[3:6], types(page))
+	assert.True(t, meta.HasMore)
+	assert.Equal(t, 3, *meta.TotalPages)
+
+	// 游标分页逐页取完与一次取出的结果一致
+	var walked []Services.ActivityEvent
+	req := Utils.PageRequest{Mode: Utils.PageModeCursor, Limit: 2}
+	for {
+		events, meta, err := service.Timeline(user.ID, Services.ActivityFilter{}, req)
+		require.NoError(t, err)
+		walked = append(walked, events...)
+		if !meta.HasMore {
+			break
+		}
+		req.Cursor = meta.Cursor
+	}
+	assert.Equal(t, all, walked)
+
+	// 按类型和时间筛选
+	since := all[4].Time
+	filtered, _, err := service.Timeline(user.ID, Services.ActivityFilter{
+		Types: []string{Services.ActivityTypeLogin, Services.ActivityTypePassword}, Since: &since,
+	}, Utils.PageRequest{Mode: Utils.PageModeOffset, Page: 1, Limit: 20})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"password"}, types(filtered))
+
+	_, _, err = service.Timeline(user.ID, Services.ActivityFilter{Types: []string{"posts"}}, Utils.PageRequest{Limit: 20})
+	assert.ErrorIs(t, err, Services.ErrInvalidActivityType)
+}
+
+func TestApiKeyActionsAppearInTimeline(t *testing.T) {
+	db := setupDB(t)
+	user := Testing.NewFactory(t, db).User()
+
+	apiKey, _, err := Services.NewApiKeyService().CreateApiKey(user.ID, "ci", &Models.ApiKeyPermission{}, "", nil)
+	require.NoError(t, err)
+	require.NoError(t, Services.NewApiKeyService().DeleteApiKey(user.ID, apiKey.ID))
+
+	events, _, err := Services.NewActivityTimelineService(db).Timeline(user.ID,
+		Services.ActivityFilter{Types: []string{Services.ActivityTypeAPIKey}}, Utils.PageRequest{Page: 1, Limit: 20})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.ElementsMatch(t, []string{"create_api_key", "delete_api_key"}, []string{events[0].Action, events[1].Action})
+}
+
+func TestMyActivityAPI(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-jwt-secret-key-for-testing-only-32-chars")
+	Config.LoadConfig()
+	db := setupDB(t)
+	factory := Testing.NewFactory(t, db)
+	user, other := factory.User(), factory.User()
+	seedActivity(t, db, user, other)
+
+	engine := gin.New()
+	userGroup := engine.Group("/api/v1/users", Middleware.NewAuthMiddleware().Handle())
+	userGroup.GET("/me/activity", Controllers.NewUserController().GetMyActivity)
+	request := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/me/activity"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+factory.Token(user))
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("?type=login,api_key&type=profile")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Data []Services.ActivityEvent `json:"data"`
+		Meta Utils.PageMeta           `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []string{"profile", "api_key", "login", "login"}, types(resp.Data))
+	assert.Equal(t, int64(4), *resp.Meta.Total)
+
+	assert.Equal(t, http.StatusBadRequest, request("?type=posts").Code)
+	assert.Equal(t, http.StatusBadRequest, request("?since=yesterday").Code)
+}
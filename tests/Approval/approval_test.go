@@ -0,0 +1,319 @@
+package Approval
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Http/Routes"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"cloud-platform-api/app/Testing"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func setupDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "approval.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.User{}, &Models.AdminApproval{}, &Models.AuditLog{}, &Models.Notification{}, &Models.AccountLockout{}))
+	return db
+}
+
+func newConfig() *Config.ApprovalConfig {
+	return &Config.ApprovalConfig{
+		Enabled:      true,
+		Actions:      Config.ApprovalActionBackupRestore + "," + Config.ApprovalActionAdminUnlock,
+		TTL:          time.Hour,
+		ExecutionTTL: 10 * time.Minute,
+	}
+}
+
+func TestApprovalLifecycle(t *testing.T) {
+	db := setupDB(t)
+	factory := Testing.NewFactory(t, db)
+	requester := factory.Admin()
+	reviewer := factory.Admin()
+	service := Services.NewApprovalService(db, newConfig())
+	now := time.Now()
+	service.SetClock(func() time.Time { return now })
+
+	assert.True(t, service.Requires(Config.ApprovalActionBackupRestore))
+	assert.False(t, service.Requires(Config.ApprovalActionSecurityConfig), "未配置的操作不需要审批")
+	assert.False(t, Services.NewApprovalService(db, &Config.ApprovalConfig{Actions: Config.ApprovalActionBackupRestore}).Requires(Config.ApprovalActionBackupRestore), "未启用时不需要审批")
+
+	req := Services.ApprovalRequest{
+		Action: Config.ApprovalActionBackupRestore, Method: http.MethodPost, Path: "/api/v1/admin/backups/1/restore",
+		Payload: []byte(`{"tables":["users"]}`), Reason: "回滚误操作", RequestedBy: requester.ID, RequesterName: requester.Username,
+	}
+	approval, err := service.Request(req)
+	require.NoError(t, err)
+	assert.Equal(t, Models.ApprovalStatusPending, approval.Status)
+	assert.WithinDuration(t, now.Add(time.Hour), approval.ExpiresAt, time.Second)
+
+	_, err = service.Claim(approval.ID, req)
+	assert.ErrorIs(t, err, Services.ErrApprovalNotApproved, "未审批不能执行")
+	_, err = service.Approve(approval.ID, requester.ID, requester.Username, "")
+	assert.ErrorIs(t, err, Services.ErrApprovalSelfReview)
+
+	approved, err := service.Approve(approval.ID, reviewer.ID, reviewer.Username, "已核对")
+	require.NoError(t, err)
+	assert.Equal(t, Models.ApprovalStatusApproved, approved.Status)
+	assert.WithinDuration(t, now.Add(10*time.Minute), approved.ExpiresAt, time.Second, "通过后为执行期限")
+	_, err = service.Reject(approval.ID, reviewer.ID, reviewer.Username, "")
+	assert.ErrorIs(t, err, Services.ErrApprovalNotPending)
+
+	// 请求必须与审批记录一致，且只能由发起人执行
+	changed := req
+	changed.Payload = []byte(`{"tables":["users","posts"]}`)
+	_, err = service.Claim(approval.ID, changed)
+	assert.ErrorIs(t, err, Services.ErrApprovalMismatch)
+	other := req
+	other.RequestedBy = reviewer.ID
+	_, err = service.Claim(approval.ID, other)
+	assert.ErrorIs(t, err, Services.ErrApprovalMismatch)
+
+	claimed, err := service.Claim(approval.ID, req)
+	require.NoError(t, err)
+	service.Complete(claimed, http.StatusOK, req)
+	_, err = service.Claim(approval.ID, req)
+	assert.ErrorIs(t, err, Services.ErrApprovalNotApproved, "只能执行一次")
+
+	var stored Models.AdminApproval
+	require.NoError(t, db.First(&stored, approval.ID).Error)
+	assert.Equal(t, Models.ApprovalStatusExecuted, stored.Status)
+	assert.Equal(t, http.StatusOK, stored.ExecutionStatus)
+	assert.Equal(t, reviewer.ID, stored.ReviewedBy)
+
+	var actions []string
+	require.NoError(t, db.Model(&Models.AuditLog{}).Where("resource = ? AND resource_id = ?", "approval", approval.ID).Order("id").Pluck("action", &actions).Error)
+	assert.Equal(t, []string{Models.AuditActionApprovalRequest, Models.AuditActionApprovalApprove, Models.AuditActionApprovalExecute}, actions)
+	var notified int64
+	require.NoError(t, db.Model(&Models.Notification{}).Where("type = ?", Models.NotificationTypeApproval).Count(&notified).Error)
+	assert.Equal(t, int64(3), notified, "两名管理员收到待审批通知，发起人收到审批结果")
+
+	// 超过审批期限或执行期限后过期
+	pending, err := service.Request(req)
+	require.NoError(t, err)
+	executable, err := service.Request(req)
+	require.NoError(t, err)
+	_, err = service.Approve(executable.ID, reviewer.ID, reviewer.Username, "")
+	require.NoError(t, err)
+	service.SetClock(func() time.Time { return now.Add(11 * time.Minute) })
+	_, err = service.Claim(executable.ID, req)
+	assert.ErrorIs(t, err, Services.ErrApprovalExpired)
+	service.SetClock(func() time.Time { return now.Add(2 * time.Hour) })
+	_, err = service.Approve(pending.ID, reviewer.ID, reviewer.Username, "")
+	assert.ErrorIs(t, err, Services.ErrApprovalExpired)
+	var expired Models.AdminApproval
+	require.NoError(t, db.First(&expired, pending.ID).Error)
+	assert.Equal(t, Models.ApprovalStatusExpired, expired.Status)
+}
+
+func TestApprovalMiddlewareAndRoutes(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-jwt-secret-key-for-testing-only-32-chars")
+	Config.LoadConfig()
+	OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "测试", Version: "1.0.0"}))
+	db := setupDB(t)
+	service := Services.NewApprovalService(db, newConfig())
+	Services.SetDefaultApprovalService(service)
+	t.Cleanup(func() {
+		Services.SetDefaultApprovalService(nil)
+		OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "Cloud Platform API", Version: "1.0.0"}))
+	})
+
+	engine := gin.New()
+	storageManager := Storage.NewStorageManager(&Config.StorageConfig{BasePath: t.TempDir()})
+	Routes.RegisterApprovalRoutes(engine, storageManager, Controllers.NewApprovalController(service))
+	executed := 0
+	engine.POST("/api/v1/admin/backups/:id/restore", Middleware.NewAuthMiddleware().Handle(),
+		Middleware.NewApprovalMiddleware(nil).Require(Config.ApprovalActionBackupRestore),
+		func(c *gin.Context) {
+			var body map[string]interface{}
+			require.NoError(t, c.ShouldBindJSON(&body), "执行时仍能读取请求体")
+			executed++
+			c.JSON(http.StatusOK, gin.H{"success": true})
+		})
+	engine.POST("/api/v1/admin/backups/:id/verify", Middleware.NewAuthMiddleware().Handle(),
+		Middleware.NewApprovalMiddleware(nil).Require(Config.ApprovalActionSecurityConfig),
+		func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"success": true}) })
+
+	factory := Testing.NewFactory(t, db)
+	requester := factory.Admin()
+	reviewer := factory.Admin()
+	request := func(user *Models.User, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+factory.Token(user))
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, request(requester, http.MethodPost, "/api/v1/admin/backups/1/verify", `{}`).Code, "未配置的操作直接执行")
+
+	body := `{"tables":["users"],"confirm":true}`
+	w := request(requester, http.MethodPost, "/api/v1/admin/backups/1/restore", body, Middleware.ApprovalReasonHeader, "回滚误操作")
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	assert.Equal(t, 0, executed)
+	var resp struct {
+		Code string               `json:"code"`
+		Data Models.AdminApproval `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "APPROVAL_REQUIRED", resp.Code)
+	assert.Equal(t, "回滚误操作", resp.Data.Reason)
+	assert.Equal(t, body, resp.Data.Payload)
+	approvalID := fmt.Sprintf("%d", resp.Data.ID)
+	approvalPath := "/api/v1/admin/approvals/" + approvalID
+
+	w = request(reviewer, http.MethodGet, "/api/v1/admin/approvals?filter[status][eq]=pending", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "/api/v1/admin/backups/1/restore")
+	assert.Equal(t, http.StatusConflict, request(requester, http.MethodPost, "/api/v1/admin/backups/1/restore", body, Middleware.ApprovalIDHeader, approvalID).Code, "未审批")
+	assert.Equal(t, http.StatusForbidden, request(requester, http.MethodPost, approvalPath+"/approve", "").Code, "不能审批自己的操作")
+	w = request(reviewer, http.MethodPost, approvalPath+"/approve", `{"comment":"已核对备份"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	assert.Equal(t, http.StatusForbidden, request(requester, http.MethodPost, "/api/v1/admin/backups/1/restore", `{"tables":["posts"],"confirm":true}`, Middleware.ApprovalIDHeader, approvalID).Code, "请求体不一致")
+	assert.Equal(t, http.StatusForbidden, request(requester, http.MethodPost, "/api/v1/admin/backups/2/restore", body, Middleware.ApprovalIDHeader, approvalID).Code, "路径不一致")
+	assert.Equal(t, http.StatusNotFound, request(requester, http.MethodPost, "/api/v1/admin/backups/1/restore", body, Middleware.ApprovalIDHeader, "9999").Code)
+	w = request(requester, http.MethodPost, "/api/v1/admin/backups/1/restore", body, Middleware.ApprovalIDHeader, approvalID)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 1, executed)
+	assert.Equal(t, http.StatusConflict, request(requester, http.MethodPost, "/api/v1/admin/backups/1/restore", body, Middleware.ApprovalIDHeader, approvalID).Code, "只能执行一次")
+	assert.Equal(t, 1, executed)
+
+	w = request(reviewer, http.MethodGet, approvalPath, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var detail struct {
+		Data Models.AdminApproval `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &detail))
+	assert.Equal(t, Models.ApprovalStatusExecuted, detail.Data.Status)
+	assert.Equal(t, "已核对备份", detail.Data.ReviewComment)
+	assert.Equal(t, http.StatusOK, detail.Data.ExecutionStatus)
+
+	// 拒绝后不能执行
+	w = request(requester, http.MethodPost, "/api/v1/admin/backups/3/restore", body)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	rejectPath := fmt.Sprintf("/api/v1/admin/approvals/%d/reject", resp.Data.ID)
+	assert.Equal(t, http.StatusOK, request(reviewer, http.MethodPost, rejectPath, `{"comment":"不需要恢复"}`).Code)
+	assert.Equal(t, http.StatusConflict, request(reviewer, http.MethodPost, rejectPath, "").Code)
+	assert.Equal(t, http.StatusConflict, request(requester, http.MethodPost, "/api/v1/admin/backups/3/restore", body,
+		Middleware.ApprovalIDHeader, fmt.Sprintf("%d", resp.Data.ID)).Code)
+	assert.Equal(t, 1, executed)
+}
+
+func TestUnlockRequiresApprovalOnlyForAdmins(t *testing.T) {
+	db := setupDB(t)
+	factory := Testing.NewFactory(t, db)
+	admin := factory.Admin()
+	user := factory.User()
+	lockoutService := Services.NewAccountLockoutService(db, &Config.AccountUnlockConfig{}, nil)
+	lock := func(u *Models.User, ip string) *Models.AccountLockout {
+		lockout := &Models.AccountLockout{UserID: u.ID, Username: u.Username, IPAddress: ip, LockoutType: "account",
+			LockoutTime: time.Now(), ExpiryTime: time.Now().Add(time.Hour), Active: true}
+		require.NoError(t, db.Create(lockout).Error)
+		return lockout
+	}
+	adminLock := lock(admin, "10.0.0.1")
+	userLock := lock(user, "10.0.0.2")
+
+	locksAdmin := func(id uint, username, ip string) bool {
+		ok, err := lockoutService.LocksAdmin(id, username, ip)
+		require.NoError(t, err)
+		return ok
+	}
+	assert.True(t, locksAdmin(adminLock.ID, "", ""))
+	assert.False(t, locksAdmin(userLock.ID, "", ""))
+	assert.True(t, locksAdmin(0, admin.Username, ""))
+	assert.False(t, locksAdmin(0, user.Username, ""))
+	assert.True(t, locksAdmin(0, "", "10.0.0.1"), "按IP解锁会解除管理员的锁定")
+	assert.False(t, locksAdmin(0, "", "10.0.0.2"))
+
+	_, err := lockoutService.Unlock(adminLock.ID, user.ID, "测试")
+	require.NoError(t, err)
+	assert.False(t, locksAdmin(adminLock.ID, "", ""), "已解除的锁定不需要审批")
+}
+
+// TestAlertRuleDeleteRequiresApproval 应用注册的删除告警规则路由使用审批路由同一个审批服务
+func TestAlertRuleDeleteRequiresApproval(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-jwt-secret-key-for-testing-only-32-chars")
+	t.Setenv("LOG_BASE_PATH", t.TempDir())
+	t.Setenv("APPROVAL_ENABLED", "true")
+	t.Setenv("APPROVAL_ACTIONS", Config.ApprovalActionAlertRuleDelete)
+	Config.LoadConfig()
+	db := setupDB(t)
+	require.NoError(t, db.AutoMigrate(&Models.AlertRule{}))
+	previous := Database.DB
+	Database.DB = db
+	t.Cleanup(func() {
+		Database.DB = previous
+		Services.SetDefaultApprovalService(nil)
+		OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "Cloud Platform API", Version: "1.0.0"}))
+	})
+
+	OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "测试", Version: "1.0.0"}))
+	engine := gin.New()
+	Routes.RegisterRoutes(engine, Storage.NewStorageManager(&Config.StorageConfig{BasePath: t.TempDir()}), Services.NewLogManagerService(&Config.GetConfig().Log))
+
+	factory := Testing.NewFactory(t, db)
+	requester := factory.Admin()
+	reviewer := factory.Admin()
+	request := func(user *Models.User, method, path string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+factory.Token(user))
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	rule := Models.AlertRule{Name: "cpu", Type: "threshold", MetricType: "system", MetricName: "cpu_usage", Condition: ">", Threshold: 80, Severity: "warning", Enabled: true, CreatedBy: requester.ID}
+	require.NoError(t, db.Create(&rule).Error)
+	rulePath := fmt.Sprintf("/api/v1/monitoring/alert-rules/%d", rule.ID)
+
+	w := request(requester, http.MethodDelete, rulePath)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	var resp struct {
+		Data Models.AdminApproval `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, Config.ApprovalActionAlertRuleDelete, resp.Data.Action)
+	require.NoError(t, db.First(&Models.AlertRule{}, rule.ID).Error, "审批通过前不删除")
+
+	approvalID := fmt.Sprintf("%d", resp.Data.ID)
+	w = request(reviewer, http.MethodPost, "/api/v1/admin/approvals/"+approvalID+"/approve")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = request(requester, http.MethodDelete, rulePath, Middleware.ApprovalIDHeader, approvalID)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.ErrorIs(t, db.First(&Models.AlertRule{}, rule.ID).Error, gorm.ErrRecordNotFound)
+}
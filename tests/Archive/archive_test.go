@@ -0,0 +1,249 @@
+package Archive
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupArchiveService 创建使用SQLite和本地归档目录的归档服务，在线保留30天
+func setupArchiveService(t *testing.T) (*gorm.DB, *Services.TableArchiveService, string) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "archive.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.SecurityEvent{}, &Models.LoginAttempt{}, &Models.MonitoringMetric{}, &Models.TablePartition{}))
+
+	archiveDir := t.TempDir()
+	config := &Config.ArchiveConfig{
+		Enabled:       true,
+		Tables:        "security_events, login_attempts",
+		HotRetention:  30 * 24 * time.Hour,
+		CheckInterval: time.Hour,
+		Store:         Config.ArchiveStoreLocal,
+		LocalPath:     archiveDir,
+		CacheSize:     2,
+	}
+	require.NoError(t, config.Validate())
+	return db, Services.NewTableArchiveService(db, config, Services.NewLocalArchiveStore(archiveDir)), archiveDir
+}
+
+// createSecurityEvent 写入指定时间的安全事件
+func createSecurityEvent(t *testing.T, db *gorm.DB, eventType string, at time.Time) *Models.SecurityEvent {
+	event := &Models.SecurityEvent{EventType: eventType, EventLevel: "medium", IPAddress: "10.0.0.1", CreatedAt: at, UpdatedAt: at}
+	require.NoError(t, db.Create(event).Error)
+	return event
+}
+
+// eventDays 返回事件的日期，用于断言顺序
+func eventDays(events []Models.SecurityEvent) []string {
+	days := make([]string, 0, len(events))
+	for _, event := range events {
+		days = append(days, event.CreatedAt.UTC().Format("01-02"))
+	}
+	return days
+}
+
+func day(month time.Month, d int) time.Time {
+	return time.Date(2024, month, d, 12, 0, 0, 0, time.UTC)
+}
+
+func TestMonthlyRotationAndArchival(t *testing.T) {
+	db, service, archiveDir := setupArchiveService(t)
+	createSecurityEvent(t, db, "login_failed", day(3, 10))
+	createSecurityEvent(t, db, "sql_injection", day(4, 10))
+	deleted := createSecurityEvent(t, db, "login_failed", day(4, 20))
+	createSecurityEvent(t, db, "login_failed", day(5, 10))
+	createSecurityEvent(t, db, "xss", day(6, 1))
+	createSecurityEvent(t, db, "login_failed", day(6, 10))
+	require.NoError(t, db.Delete(deleted).Error)
+
+	// 6月：早于本月的数据轮转到月表，5月底的上界仍在保留期内，不归档
+	now := day(6, 15)
+	service.SetClock(func() time.Time { return now })
+	result, err := service.Run(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, []string{"security_events_p202405"}, result.Created)
+	assert.Empty(t, result.Archived)
+
+	var live int64
+	db.Unscoped().Table("security_events").Count(&live)
+	assert.Equal(t, int64(2), live, "在线表只保留当月数据")
+
+	// 7月：6月数据轮转，5月月表超过保留期后归档并删除
+	createSecurityEvent(t, db, "xss", day(7, 2))
+	now = day(7, 15)
+	result, err = service.Run(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, []string{"security_events_p202406"}, result.Created)
+	assert.Equal(t, []string{"security_events_p202405"}, result.Archived)
+	assert.False(t, db.Migrator().HasTable("security_events_p202405"))
+
+	partitions, err := service.Partitions("security_events")
+	require.NoError(t, err)
+	require.Len(t, partitions, 2)
+	archived := partitions[1]
+	assert.Equal(t, Models.TablePartitionArchived, archived.Status)
+	assert.Equal(t, int64(4), archived.RowCount, "软删除的记录也写入归档")
+	assert.Equal(t, day(3, 10), archived.RangeStart.UTC())
+	assert.Len(t, archived.Checksum, 64)
+	_, err = os.Stat(filepath.Join(archiveDir, "security_events", "security_events_p202405.jsonl.gz"))
+	assert.NoError(t, err)
+
+	// 再次执行不重复轮转或归档
+	result, err = service.Run(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, result.Created)
+	assert.Empty(t, result.Archived)
+}
+
+func TestArchiveQueryMergesLiveAndArchive(t *testing.T) {
+	db, service, _ := setupArchiveService(t)
+	createSecurityEvent(t, db, "login_failed", day(3, 10))
+	createSecurityEvent(t, db, "sql_injection", day(4, 10))
+	deleted := createSecurityEvent(t, db, "login_failed", day(4, 20))
+	createSecurityEvent(t, db, "login_failed", day(5, 10))
+	createSecurityEvent(t, db, "xss", day(6, 1))
+	createSecurityEvent(t, db, "login_failed", day(6, 10))
+	require.NoError(t, db.Delete(deleted).Error)
+
+	now := day(6, 15)
+	service.SetClock(func() time.Time { return now })
+	_, err := service.Run(context.Background())
+	require.NoError(t, err)
+	createSecurityEvent(t, db, "xss", day(7, 2))
+	now = day(7, 15)
+	_, err = service.Run(context.Background())
+	require.NoError(t, err)
+
+	var events []Models.SecurityEvent
+	total, err := service.Query(context.Background(), "security_events", Services.ArchiveQuery{Limit: 10}, &events)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), total, "软删除的记录不返回")
+	assert.Equal(t, []string{"07-02", "06-10", "06-01", "05-10", "04-10", "03-10"}, eventDays(events))
+
+	// 跨越月表和归档文件分页
+	total, err = service.Query(context.Background(), "security_events", Services.ArchiveQuery{Offset: 2, Limit: 3}, &events)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), total)
+	assert.Equal(t, []string{"06-01", "05-10", "04-10"}, eventDays(events))
+
+	// 时间范围和条件过滤同时作用于在线数据和归档数据
+	total, err = service.Query(context.Background(), "security_events", Services.ArchiveQuery{
+		From: day(4, 1), To: day(6, 5), Where: map[string]interface{}{"event_type": "login_failed"},
+	}, &events)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, []string{"05-10"}, eventDays(events))
+
+	total, err = service.Query(context.Background(), "security_events", Services.ArchiveQuery{From: day(4, 1), Limit: 2, Ascending: true}, &events)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	assert.Equal(t, []string{"04-10", "05-10"}, eventDays(events))
+
+	var attempts []Models.LoginAttempt
+	_, err = service.Query(context.Background(), "security_events", Services.ArchiveQuery{}, &attempts)
+	assert.Error(t, err, "结果类型与表模型不一致")
+	_, err = service.Query(context.Background(), "monitoring_metrics", Services.ArchiveQuery{}, &events)
+	assert.True(t, errors.Is(err, Services.ErrArchiveTableNotSupported))
+	_, err = service.Query(context.Background(), "security_events", Services.ArchiveQuery{Where: map[string]interface{}{"1=1; --": 1}}, &events)
+	assert.Error(t, err, "未知的列")
+
+	// 管理接口按表名查询
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/archive/tables/:table/rows", Controllers.NewArchiveController(service).QueryTable)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/archive/tables/security_events/rows?page=2&page_size=4", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data []Models.SecurityEvent `json:"data"`
+		Meta struct {
+			Total int64 `json:"total"`
+		} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, int64(6), response.Meta.Total)
+	assert.Equal(t, []string{"04-10", "03-10"}, eventDays(response.Data))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/archive/tables/users/rows", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestS3ArchiveStore(t *testing.T) {
+	var mu sync.Mutex
+	objects := make(map[string][]byte)
+	var authorizations []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			objects[r.URL.Path] = body
+		case http.MethodGet:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(data)
+		}
+	}))
+	defer server.Close()
+
+	store := Services.NewS3ArchiveStore(server.URL, "us-east-1", "audit", "AKID", "secret", "archive/", true, server.Client())
+	require.NoError(t, store.Put(context.Background(), "security_events/p202401.jsonl.gz", []byte("data")))
+	data, err := store.Get(context.Background(), "security_events/p202401.jsonl.gz")
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+	_, err = store.Get(context.Background(), "missing.jsonl.gz")
+	assert.True(t, errors.Is(err, Services.ErrArchiveObjectNotFound))
+
+	mu.Lock()
+	_, stored := objects["/audit/archive/security_events/p202401.jsonl.gz"]
+	assert.True(t, stored, "路径形式访问存储桶并添加对象键前缀")
+	assert.True(t, strings.HasPrefix(authorizations[0], "AWS4-HMAC-SHA256 Credential=AKID/"))
+	assert.Contains(t, authorizations[0], "/us-east-1/s3/aws4_request")
+	mu.Unlock()
+
+	// 本地存储的对象键不能跳出归档目录
+	root := t.TempDir()
+	local := Services.NewLocalArchiveStore(filepath.Join(root, "archive"))
+	require.NoError(t, local.Put(context.Background(), "../../escape.gz", []byte("x")))
+	_, err = os.Stat(filepath.Join(root, "archive", "escape.gz"))
+	assert.NoError(t, err)
+	_, err = local.Get(context.Background(), "missing.gz")
+	assert.True(t, errors.Is(err, Services.ErrArchiveObjectNotFound))
+
+	config := &Config.ArchiveConfig{Enabled: true, Tables: "security_events", HotRetention: 48 * time.Hour, CheckInterval: time.Hour, Store: "s3"}
+	assert.Error(t, config.Validate(), "未配置存储桶")
+	config.S3.Endpoint, config.S3.Bucket, config.S3.AccessKeyID, config.S3.SecretAccessKey = server.URL, "audit", "AKID", "secret"
+	assert.NoError(t, config.Validate())
+	config.HotRetention = time.Hour
+	assert.Error(t, config.Validate())
+}
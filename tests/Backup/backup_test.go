@@ -0,0 +1,158 @@
+package Backup
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupBackupService 创建带 users 和 migrations 表的SQLite数据库，以及备份到临时目录的备份服务
+func setupBackupService(t *testing.T, compression bool) (*Services.BackupService, string) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "app.db")
+	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, username TEXT)").Error)
+	require.NoError(t, db.Exec("CREATE TABLE migrations (id INTEGER PRIMARY KEY, migration TEXT)").Error)
+	require.NoError(t, db.Exec("INSERT INTO users (username) VALUES ('alice'), ('bob')").Error)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+
+	storageManager := Storage.NewStorageManager(&Config.StorageConfig{BasePath: filepath.Join(dir, "storage")})
+	service := Services.NewBackupService(storageManager, &Services.BackupConfig{
+		BackupPath:           filepath.Join(dir, "backups"),
+		EnableCompression:    compression,
+		RestoreDrillInterval: 24 * time.Hour,
+		RestoreDrillTables:   []string{"users", "migrations"},
+	})
+	service.SetDatabaseConfig(&Config.DatabaseConfig{Driver: "sqlite", Database: dbPath})
+	return service, dir
+}
+
+func TestDatabaseBackupWritesVerifiableChecksum(t *testing.T) {
+	service, _ := setupBackupService(t, true)
+
+	info, err := service.CreateDatabaseBackup()
+	require.NoError(t, err)
+	assert.Len(t, info.SHA256, 64)
+	assert.FileExists(t, info.Path+".sha256")
+
+	verification, err := service.VerifyBackup(info.Path)
+	require.NoError(t, err)
+	assert.True(t, verification.ChecksumOK)
+	assert.True(t, verification.ArchiveOK)
+	assert.Equal(t, info.SHA256, verification.SHA256)
+
+	// 列表中不出现校验和文件，压缩后的备份带有校验和
+	backups, err := service.ListBackups()
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	assert.Equal(t, info.SHA256, backups[0].SHA256)
+}
+
+func TestVerifyBackupDetectsTampering(t *testing.T) {
+	service, _ := setupBackupService(t, true)
+	info, err := service.CreateDatabaseBackup()
+	require.NoError(t, err)
+
+	file, err := os.OpenFile(info.Path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = file.WriteString("tampered")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	verification, err := service.VerifyBackup(info.Path)
+	assert.ErrorIs(t, err, Services.ErrBackupChecksumMismatch)
+	assert.False(t, verification.ChecksumOK)
+
+	// 恢复前同样校验，不会用被修改的备份覆盖数据
+	assert.Error(t, service.RestoreBackup(info.Path, "database"))
+
+	require.NoError(t, os.Remove(info.Path+".sha256"))
+	_, err = service.VerifyBackup(info.Path)
+	assert.ErrorIs(t, err, Services.ErrBackupChecksumMissing)
+}
+
+func TestRestoreDrillSucceeds(t *testing.T) {
+	service, dir := setupBackupService(t, true)
+	_, err := service.CreateDatabaseBackup()
+	require.NoError(t, err)
+
+	result, err := service.RunRestoreDrill(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Services.RestoreDrillSuccess, result.Status)
+	assert.True(t, result.Checksum)
+	assert.Equal(t, int64(2), result.Tables["users"])
+	assert.Equal(t, int64(0), result.Tables["migrations"])
+
+	// 临时目录在演练后删除
+	entries, err := os.ReadDir(filepath.Join(dir, "backups"))
+	require.NoError(t, err)
+	for _, entry := range entries {
+		assert.False(t, entry.IsDir(), entry.Name())
+	}
+
+	values, err := service.Collector().Collect(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, values["backup_restore_drill_success"])
+	assert.Contains(t, values, "backup_restore_drill_duration_seconds")
+	assert.Equal(t, float64(result.FinishedAt.Unix()), values["backup_restore_drill_last_run_timestamp"])
+}
+
+func TestRestoreDrillFailsOnMissingTable(t *testing.T) {
+	service, dir := setupBackupService(t, false)
+	_, err := service.CreateDatabaseBackup()
+	require.NoError(t, err)
+
+	drillService := Services.NewBackupService(Storage.NewStorageManager(&Config.StorageConfig{BasePath: filepath.Join(dir, "storage")}), &Services.BackupConfig{
+		BackupPath:         filepath.Join(dir, "backups"),
+		RestoreDrillTables: []string{"users", "posts"},
+	})
+	drillService.SetDatabaseConfig(&Config.DatabaseConfig{Driver: "sqlite", Database: filepath.Join(dir, "app.db")})
+
+	result, err := drillService.RunRestoreDrill(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, Services.RestoreDrillFailed, result.Status)
+	assert.Contains(t, result.Error, "posts")
+
+	values, err := drillService.Collector().Collect(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, values["backup_restore_drill_success"])
+
+	// 演练结果保存在备份目录，新建的服务实例仍能读取
+	reloaded := Services.NewBackupService(Storage.NewStorageManager(&Config.StorageConfig{BasePath: filepath.Join(dir, "storage")}), &Services.BackupConfig{
+		BackupPath: filepath.Join(dir, "backups"),
+	})
+	require.NotNil(t, reloaded.LastRestoreDrill())
+	assert.Equal(t, Services.RestoreDrillFailed, reloaded.LastRestoreDrill().Status)
+}
+
+func TestRestoreDrillWithoutBackups(t *testing.T) {
+	service, _ := setupBackupService(t, true)
+
+	result, err := service.RunRestoreDrill(context.Background())
+	assert.ErrorIs(t, err, Services.ErrNoRestorableBackup)
+	assert.Equal(t, Services.RestoreDrillFailed, result.Status)
+}
+
+func TestRestoreDrillAlertRules(t *testing.T) {
+	service, _ := setupBackupService(t, true)
+
+	rules := service.AlertRules()
+	require.Len(t, rules, 2)
+	assert.Equal(t, "backup_restore_drill_success", rules[0].Metric)
+	assert.Equal(t, Services.AlertLevelCritical, rules[0].Level)
+	assert.Equal(t, (48 * time.Hour).Seconds(), rules[1].Threshold)
+}
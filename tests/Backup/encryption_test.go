@@ -0,0 +1,260 @@
+package Backup
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newKey(t *testing.T) string {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+// newEncryptedService 在 setupBackupService 创建的目录上创建启用加密的备份服务
+func newEncryptedService(t *testing.T, dir string, configure func(*Services.BackupConfig)) *Services.BackupService {
+	config := &Services.BackupConfig{
+		BackupPath:         filepath.Join(dir, "backups"),
+		EnableCompression:  true,
+		EnableEncryption:   true,
+		RestoreDrillTables: []string{"users"},
+	}
+	configure(config)
+	service := Services.NewBackupService(Storage.NewStorageManager(&Config.StorageConfig{BasePath: filepath.Join(dir, "storage")}), config)
+	service.SetDatabaseConfig(&Config.DatabaseConfig{Driver: "sqlite", Database: filepath.Join(dir, "app.db")})
+	return service
+}
+
+func countUsers(t *testing.T, dbPath string) int64 {
+	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+	var count int64
+	require.NoError(t, db.Table("users").Count(&count).Error)
+	return count
+}
+
+func TestEncryptedBackupRoundTrip(t *testing.T) {
+	_, dir := setupBackupService(t, true)
+	key := newKey(t)
+	service := newEncryptedService(t, dir, func(config *Services.BackupConfig) {
+		config.EncryptionKey = key
+		config.EncryptionKeyVersion = "2024-01"
+	})
+
+	info, err := service.CreateDatabaseBackup()
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(info.Path, ".sql.gz.enc"))
+	assert.NoFileExists(t, strings.TrimSuffix(info.Path, ".enc"))
+	assert.Equal(t, "2024-01", info.Metadata["encryption"].(map[string]interface{})["key_version"])
+
+	header, err := Services.ReadBackupEncryptionHeader(info.Path)
+	require.NoError(t, err)
+	assert.Equal(t, "AES-256-GCM", header.Algorithm)
+	assert.Equal(t, "local", header.KeyProvider)
+
+	backups, err := service.ListBackups()
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	assert.Equal(t, "2024-01", backups[0].Metadata["encryption"].(map[string]interface{})["key_version"])
+
+	verification, err := service.VerifyBackup(info.Path)
+	require.NoError(t, err)
+	assert.True(t, verification.ArchiveOK)
+
+	output := filepath.Join(t.TempDir(), "db.sql.gz")
+	require.NoError(t, service.DecryptBackup(context.Background(), info.Path, output))
+	assert.FileExists(t, output)
+
+	result, err := service.RunRestoreDrill(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.Tables["users"])
+}
+
+func TestEncryptedBackupRestore(t *testing.T) {
+	_, dir := setupBackupService(t, false)
+	key := newKey(t)
+	service := newEncryptedService(t, dir, func(config *Services.BackupConfig) {
+		config.EnableCompression = false
+		config.EncryptionKey = key
+	})
+	info, err := service.CreateDatabaseBackup()
+	require.NoError(t, err)
+
+	// 备份文件中不出现明文数据库
+	data, err := os.ReadFile(info.Path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "SQLite format")
+
+	dbPath := filepath.Join(dir, "app.db")
+	require.NoError(t, os.WriteFile(dbPath, nil, 0644))
+	require.NoError(t, service.RestoreBackup(info.Path, "database"))
+	assert.Equal(t, int64(2), countUsers(t, dbPath))
+
+	// 解密用的临时目录已删除
+	entries, err := os.ReadDir(filepath.Join(dir, "backups"))
+	require.NoError(t, err)
+	for _, entry := range entries {
+		assert.False(t, entry.IsDir(), entry.Name())
+	}
+}
+
+func TestEncryptedBackupDetectsTampering(t *testing.T) {
+	_, dir := setupBackupService(t, true)
+	service := newEncryptedService(t, dir, func(config *Services.BackupConfig) {
+		config.EncryptionKey = newKey(t)
+	})
+	info, err := service.CreateDatabaseBackup()
+	require.NoError(t, err)
+
+	// 修改一个字节并重写校验和文件，只能由GCM认证发现
+	data, err := os.ReadFile(info.Path)
+	require.NoError(t, err)
+	data[len(data)-20] ^= 0xff
+	require.NoError(t, os.WriteFile(info.Path, data, 0600))
+	require.NoError(t, os.Remove(info.Path+".sha256"))
+
+	output := filepath.Join(t.TempDir(), "db.sql.gz")
+	err = service.DecryptBackup(context.Background(), info.Path, output)
+	assert.ErrorIs(t, err, Services.ErrBackupDecryptFailed)
+	assert.NoFileExists(t, output)
+	assert.Error(t, service.RestoreBackup(info.Path, "database"))
+
+	// 截断最后一个分块同样失败
+	require.NoError(t, os.WriteFile(info.Path, data[:len(data)-40], 0600))
+	assert.ErrorIs(t, service.DecryptBackup(context.Background(), info.Path, output), Services.ErrBackupDecryptFailed)
+}
+
+func TestEncryptedBackupKeyRotation(t *testing.T) {
+	_, dir := setupBackupService(t, true)
+	oldKey, newKeyValue := newKey(t), newKey(t)
+	oldService := newEncryptedService(t, dir, func(config *Services.BackupConfig) {
+		config.EncryptionKey = oldKey
+		config.EncryptionKeyVersion = "v1"
+	})
+	info, err := oldService.CreateDatabaseBackup()
+	require.NoError(t, err)
+
+	// 轮换后未保留旧密钥时无法解密
+	rotated := newEncryptedService(t, dir, func(config *Services.BackupConfig) {
+		config.EncryptionKey = newKeyValue
+		config.EncryptionKeyVersion = "v2"
+	})
+	output := filepath.Join(t.TempDir(), "db.sql.gz")
+	assert.ErrorIs(t, rotated.DecryptBackup(context.Background(), info.Path, output), Services.ErrBackupKeyVersionUnknown)
+
+	rotated = newEncryptedService(t, dir, func(config *Services.BackupConfig) {
+		config.EncryptionKey = newKeyValue
+		config.EncryptionKeyVersion = "v2"
+		config.EncryptionPreviousKeys = []string{"v1:" + oldKey}
+	})
+	require.NoError(t, rotated.DecryptBackup(context.Background(), info.Path, output))
+}
+
+func TestEncryptedBackupEscrow(t *testing.T) {
+	_, dir := setupBackupService(t, true)
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+	keyDir := t.TempDir()
+	publicPath := filepath.Join(keyDir, "escrow.pub.pem")
+	privatePath := filepath.Join(keyDir, "escrow.pem")
+	require.NoError(t, os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0644))
+	require.NoError(t, os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}), 0600))
+
+	service := newEncryptedService(t, dir, func(config *Services.BackupConfig) {
+		config.EncryptionKey = newKey(t)
+		config.EscrowPublicKey = publicPath
+	})
+	info, err := service.CreateDatabaseBackup()
+	require.NoError(t, err)
+	assert.NotEmpty(t, info.Metadata["encryption"].(map[string]interface{})["escrow_key_id"])
+
+	// 主密钥丢失后用托管私钥恢复
+	loaded, err := Services.LoadEscrowPrivateKey(privatePath)
+	require.NoError(t, err)
+	output := filepath.Join(t.TempDir(), "db.sql.gz")
+	require.NoError(t, Services.DecryptBackupWithEscrow(info.Path, output, loaded))
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	assert.Error(t, Services.DecryptBackupWithEscrow(info.Path, output, otherKey))
+}
+
+func TestEncryptedBackupWithVaultTransit(t *testing.T) {
+	// 模拟Vault Transit：包装结果为 vault:v3:<base64>
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test-token", r.Header.Get("X-Vault-Token"))
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		switch r.URL.Path {
+		case "/v1/transit/encrypt/backup":
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]string{"ciphertext": "vault:v3:" + body["plaintext"]}})
+		case "/v1/transit/decrypt/backup":
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]string{"plaintext": strings.TrimPrefix(body["ciphertext"], "vault:v3:")}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	_, dir := setupBackupService(t, true)
+	service := newEncryptedService(t, dir, func(config *Services.BackupConfig) {
+		config.EncryptionKeyProvider = "vault"
+		config.VaultAddress = server.URL
+		config.VaultToken = "test-token"
+		config.VaultTransitKey = "backup"
+	})
+	info, err := service.CreateDatabaseBackup()
+	require.NoError(t, err)
+
+	header, err := Services.ReadBackupEncryptionHeader(info.Path)
+	require.NoError(t, err)
+	assert.Equal(t, "vault", header.KeyProvider)
+	assert.Equal(t, "v3", header.KeyVersion)
+
+	_, err = service.VerifyBackup(info.Path)
+	require.NoError(t, err)
+}
+
+func TestEncryptionConfigValidation(t *testing.T) {
+	config := &Config.StorageConfig{UploadPath: "./uploads", MaxFileSize: 10, AllowedTypes: []string{"jpg"}, EnableEncryption: true}
+	assert.Error(t, config.Validate())
+
+	config.EncryptionKey = "not-a-key"
+	assert.Error(t, config.Validate())
+
+	config.EncryptionKey = newKey(t)
+	config.EncryptionKeyVersion = "v1"
+	assert.NoError(t, config.Validate())
+
+	config.EncryptionPreviousKeys = []string{"missing-version"}
+	assert.Error(t, config.Validate())
+
+	config.EncryptionPreviousKeys = nil
+	config.EncryptionKeyProvider = "vault"
+	assert.Error(t, config.Validate())
+}
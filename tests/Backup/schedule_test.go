@@ -0,0 +1,212 @@
+package Backup
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupScheduleService 创建定时备份服务，计划和运行记录保存在单独的数据库，备份的是 setupBackupService 创建的数据库
+func setupScheduleService(t *testing.T) (*Services.BackupScheduleService, *gorm.DB, string) {
+	backups, dir := setupBackupService(t, true)
+	db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "meta.db")+"?_busy_timeout=5000"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.BackupSchedule{}, &Models.BackupRun{}, &Models.User{}, &Models.Notification{}))
+	require.NoError(t, db.Create(&Models.User{Username: "admin", Email: "admin@example.com", Password: "x", Role: "admin", Status: 1}).Error)
+
+	service := Services.NewBackupScheduleService(db, backups, &Config.StorageConfig{BackupSchedulesEnabled: true, BackupScheduleCheckInterval: time.Minute})
+	service.SetNotificationService(Services.NewNotificationService(db, &Config.NotificationConfig{Enabled: true}))
+	return service, db, dir
+}
+
+func TestBackupScheduleValidation(t *testing.T) {
+	service, _, _ := setupScheduleService(t)
+
+	invalid := []Services.BackupScheduleInput{
+		{Name: "", Type: "database", Schedule: "0 3 * * *"},
+		{Name: "每日", Type: "incremental", Schedule: "0 3 * * *"},
+		{Name: "每日", Type: "database", Schedule: "every day"},
+		{Name: "每日", Type: "tables", Schedule: "0 3 * * *"},
+		{Name: "每日", Type: "tables", Domains: []string{"unknown"}, Schedule: "0 3 * * *"},
+		{Name: "每日", Type: "database", Tables: []string{"users"}, Schedule: "0 3 * * *"},
+		{Name: "每日", Type: "database", Target: "archive", Schedule: "0 3 * * *"},
+		{Name: "每日", Type: "database", RetentionCount: -1, Schedule: "0 3 * * *"},
+	}
+	for _, input := range invalid {
+		_, err := service.CreateSchedule(input, 1)
+		assert.ErrorIs(t, err, Services.ErrInvalidBackupSchedule, "%+v", input)
+	}
+
+	schedule, err := service.CreateSchedule(Services.BackupScheduleInput{Name: "安全数据", Type: "tables", Domains: []string{"security", " "}, Schedule: "0 3 * * *"}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "security", schedule.Domains)
+	assert.Equal(t, Services.BackupTargetLocal, schedule.Target)
+	require.NotNil(t, schedule.NextRunAt)
+	assert.Equal(t, 3, schedule.NextRunAt.Hour())
+
+	_, err = service.GetSchedule(schedule.ID + 1)
+	assert.ErrorIs(t, err, Services.ErrBackupScheduleNotFound)
+}
+
+func TestBackupScheduleRunsDueWithRetentionAndUpload(t *testing.T) {
+	service, db, dir := setupScheduleService(t)
+	store := Services.NewLocalArchiveStore(filepath.Join(dir, "archive"))
+	service.SetObjectStore(store)
+
+	schedule, err := service.CreateSchedule(Services.BackupScheduleInput{
+		Name: "每日数据库", Type: "database", Schedule: "0 3 * * *", Target: "archive", RetentionCount: 1,
+	}, 1)
+	require.NoError(t, err)
+	require.NoError(t, db.Model(schedule).Update("next_run_at", time.Now().Add(-time.Minute)).Error)
+
+	assert.Equal(t, 1, service.RunDueSchedules(context.Background(), time.Now()))
+	// 已抢占的计划不会重复运行
+	assert.Equal(t, 0, service.RunDueSchedules(context.Background(), time.Now()))
+
+	// 备份文件名精确到秒
+	time.Sleep(1100 * time.Millisecond)
+	assert.Equal(t, 1, service.RunDueSchedules(context.Background(), time.Now().Add(48*time.Hour)))
+
+	runs, err := service.ListRuns(schedule.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	for _, run := range runs {
+		assert.Equal(t, Models.BackupRunSuccess, run.Status, run.Error)
+		assert.Equal(t, Services.BackupTriggerSchedule, run.Trigger)
+		assert.Equal(t, 100, run.Progress)
+		data, err := store.Get(context.Background(), run.ObjectKey)
+		require.NoError(t, err)
+		assert.NotEmpty(t, data)
+	}
+	_, err = store.Get(context.Background(), runs[0].ObjectKey+".sha256")
+	assert.NoError(t, err)
+
+	// 只保留最近一个备份文件
+	assert.FileExists(t, runs[0].BackupPath)
+	assert.Empty(t, runs[1].BackupPath)
+	backups, err := Services.NewBackupService(nil, &Services.BackupConfig{BackupPath: filepath.Join(dir, "backups")}).ListBackups()
+	require.NoError(t, err)
+	assert.Len(t, backups, 1)
+
+	schedule, err = service.GetSchedule(schedule.ID)
+	require.NoError(t, err)
+	assert.Equal(t, Models.BackupRunSuccess, schedule.LastStatus)
+	assert.Equal(t, runs[0].BackupID, schedule.LastBackupID)
+	assert.Equal(t, 2, schedule.RunCount)
+	assert.True(t, schedule.NextRunAt.After(time.Now().Add(48*time.Hour)))
+}
+
+func TestBackupScheduleFailureNotifiesAdmins(t *testing.T) {
+	service, db, _ := setupScheduleService(t)
+	schedule, err := service.CreateSchedule(Services.BackupScheduleInput{
+		Name: "文章", Type: "tables", Tables: []string{"posts"}, Schedule: "0 3 * * *",
+	}, 1)
+	require.NoError(t, err)
+	require.NoError(t, db.Model(schedule).Update("next_run_at", time.Now().Add(-time.Minute)).Error)
+	service.RunDueSchedules(context.Background(), time.Now())
+
+	schedule, err = service.GetSchedule(schedule.ID)
+	require.NoError(t, err)
+	assert.Equal(t, Models.BackupRunFailed, schedule.LastStatus)
+	assert.Contains(t, schedule.LastError, "posts")
+	assert.Equal(t, 1, schedule.ConsecutiveFailures)
+
+	var notifications []Models.Notification
+	require.NoError(t, db.Where("type = ?", Models.NotificationTypeBackup).Find(&notifications).Error)
+	require.Len(t, notifications, 1)
+	assert.Contains(t, notifications[0].Title, "失败")
+
+	// 修复后成功运行，通知恢复正常；之后的成功运行不再通知
+	_, err = service.UpdateSchedule(schedule.ID, Services.BackupScheduleInput{
+		Name: "用户", Type: "tables", Tables: []string{"users"}, Schedule: "0 3 * * *",
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Model(schedule).Update("next_run_at", time.Now().Add(-time.Minute)).Error)
+	service.RunDueSchedules(context.Background(), time.Now())
+
+	schedule, err = service.GetSchedule(schedule.ID)
+	require.NoError(t, err)
+	assert.Equal(t, Models.BackupRunSuccess, schedule.LastStatus)
+	assert.Equal(t, 0, schedule.ConsecutiveFailures)
+	require.NoError(t, db.Where("type = ?", Models.NotificationTypeBackup).Order("id").Find(&notifications).Error)
+	require.Len(t, notifications, 2)
+	assert.Contains(t, notifications[1].Title, "恢复正常")
+}
+
+func TestBackupScheduleManualTrigger(t *testing.T) {
+	service, db, _ := setupScheduleService(t)
+	schedule, err := service.CreateSchedule(Services.BackupScheduleInput{Name: "每日", Type: "database", Schedule: "0 3 * * *"}, 1)
+	require.NoError(t, err)
+
+	router := gin.New()
+	controller := Controllers.NewBackupController(nil)
+	controller.SetScheduleService(service)
+	router.POST("/api/v1/admin/backups/schedules/:id/run", controller.RunBackupSchedule)
+	router.GET("/api/v1/admin/backups/schedules/:id/runs", controller.GetBackupScheduleRuns)
+	router.GET("/api/v1/admin/backups/runs/:id", controller.GetBackupRun)
+
+	request := func(method, path string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		var body struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body.Data
+	}
+
+	status, run := request(http.MethodPost, fmt.Sprintf("/api/v1/admin/backups/schedules/%d/run", schedule.ID))
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, Services.BackupTriggerManual, run["trigger"])
+	runPath := fmt.Sprintf("/api/v1/admin/backups/runs/%v", run["id"])
+
+	// 轮询直到运行结束
+	require.Eventually(t, func() bool {
+		_, run = request(http.MethodGet, runPath)
+		return run["status"] == Models.BackupRunSuccess || run["status"] == Models.BackupRunFailed
+	}, 10*time.Second, 20*time.Millisecond)
+	assert.Equal(t, Models.BackupRunSuccess, run["status"], run["error"])
+	assert.Equal(t, float64(100), run["progress"])
+	assert.NotEmpty(t, run["backup_id"])
+
+	// 手动触发不影响下次运行时间
+	updated, err := service.GetSchedule(schedule.ID)
+	require.NoError(t, err)
+	assert.Equal(t, schedule.NextRunAt.Unix(), updated.NextRunAt.Unix())
+
+	// 同一计划有未结束的运行时拒绝
+	require.NoError(t, db.Create(&Models.BackupRun{ScheduleID: schedule.ID, Trigger: Services.BackupTriggerSchedule, Status: Models.BackupRunRunning, CreatedAt: time.Now()}).Error)
+	status, _ = request(http.MethodPost, fmt.Sprintf("/api/v1/admin/backups/schedules/%d/run", schedule.ID))
+	assert.Equal(t, http.StatusConflict, status)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/admin/backups/schedules/%d/runs?limit=1", schedule.ID), nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var runs struct {
+		Data []Models.BackupRun `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &runs))
+	require.Len(t, runs.Data, 1)
+	assert.Equal(t, Models.BackupRunRunning, runs.Data[0].Status)
+
+	status, _ = request(http.MethodPost, "/api/v1/admin/backups/schedules/999/run")
+	assert.Equal(t, http.StatusNotFound, status)
+	status, _ = request(http.MethodGet, "/api/v1/admin/backups/runs/999")
+	assert.Equal(t, http.StatusNotFound, status)
+}
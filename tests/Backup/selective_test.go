@@ -0,0 +1,183 @@
+package Backup
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Services"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openAppDB(t *testing.T, dir string) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "app.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func countRows(t *testing.T, db *gorm.DB, table string) int64 {
+	var count int64
+	require.NoError(t, db.Table(table).Count(&count).Error)
+	return count
+}
+
+func TestTableBackupRestoresOnlySelectedTables(t *testing.T) {
+	service, dir := setupBackupService(t, true)
+	db := openAppDB(t, dir)
+	require.NoError(t, db.Exec("INSERT INTO migrations (migration) VALUES ('create_users')").Error)
+
+	info, err := service.CreateTableBackup(context.Background(), Services.BackupSelection{Tables: []string{"users", "migrations"}})
+	require.NoError(t, err)
+	assert.Equal(t, "tables", info.Type)
+	assert.FileExists(t, info.Path+".manifest.json")
+
+	manifest, err := service.ReadBackupManifest(info.Path)
+	require.NoError(t, err)
+	assert.Equal(t, "jsonl", manifest.Format)
+	require.Len(t, manifest.Tables, 2)
+	assert.Equal(t, "migrations", manifest.Tables[0].Name)
+	assert.Equal(t, int64(1), manifest.Tables[0].Rows)
+	assert.Equal(t, "users", manifest.Tables[1].Name)
+	assert.Equal(t, int64(2), manifest.Tables[1].Rows)
+	assert.Equal(t, []string{"users"}, manifest.Tables[1].Domains)
+
+	require.NoError(t, db.Exec("DELETE FROM users").Error)
+	require.NoError(t, db.Exec("INSERT INTO users (username) VALUES ('mallory')").Error)
+	require.NoError(t, db.Exec("INSERT INTO migrations (migration) VALUES ('create_posts')").Error)
+
+	result, err := service.RestoreSelected(context.Background(), info.Path, Services.BackupSelection{Tables: []string{"users"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"users": 2}, result.Tables)
+
+	var usernames []string
+	require.NoError(t, db.Table("users").Order("id").Pluck("username", &usernames).Error)
+	assert.Equal(t, []string{"alice", "bob"}, usernames)
+	// 未选择的表保持恢复前的数据
+	assert.Equal(t, int64(2), countRows(t, db, "migrations"))
+
+	// 未选择时恢复备份中的全部表
+	result, err = service.RestoreSelected(context.Background(), info.Path, Services.BackupSelection{})
+	require.NoError(t, err)
+	assert.Len(t, result.Tables, 2)
+	assert.Equal(t, int64(1), countRows(t, db, "migrations"))
+}
+
+func TestTableBackupSelectsDomains(t *testing.T) {
+	service, _ := setupBackupService(t, false)
+
+	domains, err := service.ListBackupDomains()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users"}, domains["users"])
+
+	info, err := service.CreateTableBackup(context.Background(), Services.BackupSelection{Domains: []string{"users"}})
+	require.NoError(t, err)
+	require.Len(t, info.Manifest.Tables, 1)
+	assert.Equal(t, "users", info.Manifest.Tables[0].Name)
+	assert.Equal(t, []string{"users"}, info.Manifest.Domains)
+
+	_, err = service.CreateTableBackup(context.Background(), Services.BackupSelection{})
+	assert.ErrorIs(t, err, Services.ErrBackupSelectionEmpty)
+	_, err = service.CreateTableBackup(context.Background(), Services.BackupSelection{Domains: []string{"unknown"}})
+	assert.ErrorIs(t, err, Services.ErrUnknownBackupDomain)
+	_, err = service.CreateTableBackup(context.Background(), Services.BackupSelection{Tables: []string{"posts"}})
+	assert.ErrorIs(t, err, Services.ErrUnknownBackupTable)
+}
+
+func TestSelectiveRestoreFromDatabaseBackup(t *testing.T) {
+	service, dir := setupBackupService(t, true)
+	info, err := service.CreateDatabaseBackup()
+	require.NoError(t, err)
+	require.NotNil(t, info.Manifest)
+	assert.Equal(t, int64(2), info.Manifest.Tables[1].Rows)
+
+	db := openAppDB(t, dir)
+	require.NoError(t, db.Exec("DELETE FROM users WHERE username = 'bob'").Error)
+	require.NoError(t, db.Exec("INSERT INTO migrations (migration) VALUES ('create_posts')").Error)
+
+	// 数据库备份必须选择表，避免误覆盖整个数据库
+	_, err = service.RestoreSelected(context.Background(), info.Path, Services.BackupSelection{})
+	assert.ErrorIs(t, err, Services.ErrBackupSelectionEmpty)
+
+	result, err := service.RestoreSelected(context.Background(), info.Path, Services.BackupSelection{Domains: []string{"users"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"users": 2}, result.Tables)
+	assert.Equal(t, int64(2), countRows(t, db, "users"))
+	assert.Equal(t, int64(1), countRows(t, db, "migrations"))
+}
+
+func TestEncryptedTableBackupRestore(t *testing.T) {
+	_, dir := setupBackupService(t, true)
+	service := newEncryptedService(t, dir, func(config *Services.BackupConfig) {
+		config.EncryptionKey = newKey(t)
+	})
+	service.SetDB(openAppDB(t, dir))
+
+	info, err := service.CreateTableBackup(context.Background(), Services.BackupSelection{Tables: []string{"users"}})
+	require.NoError(t, err)
+	assert.True(t, Services.IsEncryptedBackup(info.Path))
+
+	// 清单不加密，列表中可直接查看
+	backups, err := service.ListBackups()
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	require.NotNil(t, backups[0].Manifest)
+	assert.Equal(t, "users", backups[0].Manifest.Tables[0].Name)
+
+	found, err := service.FindBackup(info.ID)
+	require.NoError(t, err)
+	assert.Equal(t, info.Path, found.Path)
+	_, err = service.FindBackup("missing")
+	assert.ErrorIs(t, err, Services.ErrBackupNotFound)
+
+	require.NoError(t, service.RestoreBackup(info.Path, "tables"))
+	assert.Equal(t, int64(2), countUsers(t, filepath.Join(dir, "app.db")))
+}
+
+func TestBackupControllerRestore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service, dir := setupBackupService(t, true)
+	info, err := service.CreateDatabaseBackup()
+	require.NoError(t, err)
+	db := openAppDB(t, dir)
+	require.NoError(t, db.Exec("DELETE FROM users").Error)
+
+	router := gin.New()
+	controller := Controllers.NewBackupController(service)
+	router.GET("/api/v1/admin/backups/:id/manifest", controller.GetBackupManifest)
+	router.POST("/api/v1/admin/backups/:id/restore", controller.RestoreBackupTables)
+
+	post := func(id string, body map[string]interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/backups/"+id+"/restore", bytes.NewReader(data)))
+		return w
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/backups/"+info.ID+"/manifest", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, http.StatusBadRequest, post(info.ID, map[string]interface{}{"tables": []string{"users"}}).Code)
+	assert.Equal(t, http.StatusBadRequest, post(info.ID, map[string]interface{}{"confirm": true}).Code)
+	assert.Equal(t, http.StatusBadRequest, post(info.ID, map[string]interface{}{"tables": []string{"posts"}, "confirm": true}).Code)
+	assert.Equal(t, http.StatusNotFound, post("missing", map[string]interface{}{"tables": []string{"users"}, "confirm": true}).Code)
+
+	w = post(info.ID, map[string]interface{}{"tables": []string{"users"}, "confirm": true})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, int64(2), countRows(t, db, "users"))
+}
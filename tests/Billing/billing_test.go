@@ -0,0 +1,272 @@
+package Billing
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Testing"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const webhookSecret = "whsec_test"
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func setupDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "billing.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.User{}, &Models.UsageRecord{}, &Models.UsageQuota{},
+		&Models.BillingPlan{}, &Models.BillingAccount{}, &Models.BillingEvent{}))
+	previous := Database.DB
+	Database.DB = db
+	t.Cleanup(func() { Database.DB = previous })
+	return db
+}
+
+func newService(t *testing.T, db *gorm.DB, stripe *fakeStripe) *Services.BillingService {
+	service := Services.NewBillingService(db, &Config.BillingConfig{
+		Enabled:             true,
+		StripeWebhookSecret: webhookSecret,
+		WebhookTolerance:    5 * time.Minute,
+		ProrationBehavior:   Config.BillingProrationCreate,
+		BasicHistoryRange:   24 * time.Hour,
+	}, stripe)
+	_, err := service.SavePlan(Services.BillingPlanInput{Code: "basic", Name: "基础版", StripePriceID: "price_basic", QuotaAPICalls: 2})
+	require.NoError(t, err)
+	_, err = service.SavePlan(Services.BillingPlanInput{
+		Code: "pro", Name: "专业版", StripePriceID: "price_pro", QuotaAPICalls: 5,
+		Features: []string{Services.PlanFeatureAnomalyDetection, Services.PlanFeatureLongMetricRetention},
+	})
+	require.NoError(t, err)
+	return service
+}
+
+// sign 生成 Stripe-Signature 请求头
+func sign(payload string, at time.Time) string {
+	timestamp := fmt.Sprintf("%d", at.Unix())
+	mac := hmac.New(sha256.New, []byte(webhookSecret))
+	mac.Write([]byte(timestamp + "." + payload))
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// subscriptionEvent 生成订阅事件
+func subscriptionEvent(id, eventType, status, price string, created time.Time) string {
+	return fmt.Sprintf(`{"id":%q,"type":%q,"created":%d,"data":{"object":{"id":"sub_1","customer":"cus_1","status":%q,
+		"items":{"data":[{"id":"si_1","current_period_end":%d,"price":{"id":%q}}]}}}}`,
+		id, eventType, created.Unix(), status, created.Add(30*24*time.Hour).Unix(), price)
+}
+
+func deliver(t *testing.T, service *Services.BillingService, payload string, now time.Time) error {
+	t.Helper()
+	return service.HandleWebhook([]byte(payload), sign(payload, now))
+}
+
+func TestVerifyStripeSignature(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	payload := `{"id":"evt_1"}`
+
+	assert.NoError(t, Services.VerifyStripeSignature([]byte(payload), sign(payload, now), webhookSecret, time.Minute, now))
+	// 轮换密钥期间请求头中有多个签名，任意一个匹配即可
+	assert.NoError(t, Services.VerifyStripeSignature([]byte(payload), sign(payload, now)+",v1=00ff", webhookSecret, time.Minute, now))
+
+	assert.ErrorIs(t, Services.VerifyStripeSignature([]byte(`{"id":"evt_2"}`), sign(payload, now), webhookSecret, time.Minute, now), Services.ErrInvalidStripeSignature)
+	assert.ErrorIs(t, Services.VerifyStripeSignature([]byte(payload), sign(payload, now), "whsec_other", time.Minute, now), Services.ErrInvalidStripeSignature)
+	assert.ErrorIs(t, Services.VerifyStripeSignature([]byte(payload), sign(payload, now.Add(-2*time.Minute)), webhookSecret, time.Minute, now), Services.ErrInvalidStripeSignature)
+	assert.ErrorIs(t, Services.VerifyStripeSignature([]byte(payload), "", webhookSecret, time.Minute, now), Services.ErrInvalidStripeSignature)
+}
+
+func TestWebhookSyncsSubscriptionAndQuota(t *testing.T) {
+	db := setupDB(t)
+	user := Testing.NewFactory(t, db).User()
+	stripe := &fakeStripe{}
+	service := newService(t, db, stripe)
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	service.SetClock(func() time.Time { return now })
+	metering := Services.NewMeteringService(db, &Config.MeteringConfig{Enabled: true, Period: Config.MeteringPeriodMonth, QuotaAPICalls: 1})
+	metering.SetClock(func() time.Time { return now })
+	service.SetMeteringService(metering)
+	tenant := Services.UserTenant(user.ID)
+
+	url, err := service.Checkout(context.Background(), user.ID, "pro")
+	require.NoError(t, err)
+	assert.Equal(t, "https://checkout.stripe.test/price_pro", url)
+	_, err = service.Checkout(context.Background(), user.ID, "missing")
+	assert.ErrorIs(t, err, Services.ErrBillingPlanNotFound)
+
+	// 未订阅时使用计量配置中的默认限额
+	require.NoError(t, metering.Reserve(tenant, Services.UsageMetricAPICalls, 1))
+	assert.Error(t, metering.Check(tenant, Services.UsageMetricAPICalls, 1))
+
+	// 签名无效的事件不处理
+	payload := subscriptionEvent("evt_1", "customer.subscription.created", "active", "price_basic", now)
+	assert.ErrorIs(t, service.HandleWebhook([]byte(payload), "t=1,v1=00"), Services.ErrInvalidStripeSignature)
+
+	require.NoError(t, deliver(t, service, payload, now))
+	subscription, err := service.Subscription(user.ID)
+	require.NoError(t, err)
+	require.NotNil(t, subscription.Plan)
+	assert.Equal(t, "basic", subscription.Plan.Code)
+	assert.Equal(t, "si_1", subscription.Account.StripeItemID)
+	require.NotNil(t, subscription.Account.CurrentPeriodEnd)
+	assert.NoError(t, metering.Check(tenant, Services.UsageMetricAPICalls, 1), "套餐限额应立即生效")
+
+	// 重复投递和乱序到达的旧事件被忽略
+	require.NoError(t, deliver(t, service, payload, now))
+	newer := subscriptionEvent("evt_3", "customer.subscription.updated", "active", "price_pro", now.Add(time.Minute))
+	older := subscriptionEvent("evt_2", "customer.subscription.updated", "active", "price_basic", now.Add(-time.Minute))
+	require.NoError(t, deliver(t, service, newer, now))
+	require.NoError(t, deliver(t, service, older, now))
+	subscription, err = service.Subscription(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "pro", subscription.Plan.Code)
+	var events int64
+	db.Model(&Models.BillingEvent{}).Count(&events)
+	assert.Equal(t, int64(3), events)
+
+	_, err = service.Checkout(context.Background(), user.ID, "basic")
+	assert.ErrorIs(t, err, Services.ErrBillingAlreadySubscribed)
+
+	// 订阅删除后恢复默认限额和免费功能
+	deleted := subscriptionEvent("evt_4", "customer.subscription.deleted", "active", "price_pro", now.Add(2*time.Minute))
+	require.NoError(t, deliver(t, service, deleted, now))
+	subscription, err = service.Subscription(user.ID)
+	require.NoError(t, err)
+	assert.Nil(t, subscription.Plan)
+	assert.Empty(t, subscription.Features)
+	assert.Error(t, metering.Check(tenant, Services.UsageMetricAPICalls, 1))
+}
+
+func TestChangePlanUsesPreviewProrationDate(t *testing.T) {
+	db := setupDB(t)
+	user := Testing.NewFactory(t, db).User()
+	stripe := &fakeStripe{}
+	service := newService(t, db, stripe)
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	service.SetClock(func() time.Time { return now })
+
+	_, err := service.PreviewChange(context.Background(), user.ID, "pro")
+	assert.ErrorIs(t, err, Services.ErrBillingNotSubscribed)
+
+	_, err = service.Checkout(context.Background(), user.ID, "basic")
+	require.NoError(t, err)
+	require.NoError(t, deliver(t, service, subscriptionEvent("evt_1", "customer.subscription.created", "active", "price_basic", now), now))
+
+	preview, err := service.PreviewChange(context.Background(), user.ID, "pro")
+	require.NoError(t, err)
+	assert.Equal(t, "pro", preview.Plan)
+	assert.Equal(t, now, preview.ProrationDate)
+	assert.Equal(t, Config.BillingProrationCreate, stripe.proration)
+
+	// 预览的时间点过期后需要重新预览
+	now = now.Add(2 * time.Hour)
+	_, err = service.ChangePlan(context.Background(), user.ID, "pro", preview.ProrationDate)
+	assert.ErrorIs(t, err, Services.ErrInvalidProrationDate)
+
+	now = preview.ProrationDate.Add(10 * time.Minute)
+	account, err := service.ChangePlan(context.Background(), user.ID, "pro", preview.ProrationDate)
+	require.NoError(t, err)
+	assert.Equal(t, "pro", account.PlanCode)
+	assert.Equal(t, preview.ProrationDate, stripe.prorationDate)
+
+	_, err = service.ChangePlan(context.Background(), user.ID, "pro", time.Time{})
+	assert.ErrorIs(t, err, Services.ErrInvalidBillingPlan)
+
+	account, err = service.Cancel(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.True(t, account.CancelAtPeriodEnd)
+	subscription, err := service.Subscription(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "pro", subscription.Plan.Code, "周期结束前仍可使用当前套餐")
+}
+
+func TestPlanFeatureGating(t *testing.T) {
+	db := setupDB(t)
+	user := Testing.NewFactory(t, db).User()
+	service := newService(t, db, &fakeStripe{})
+	now := time.Now().UTC()
+
+	var planErr *Services.PlanFeatureError
+	require.True(t, errors.As(service.RequireFeature(user.ID, "user", Services.PlanFeatureAnomalyDetection), &planErr))
+	assert.Equal(t, "", planErr.Plan)
+	assert.NoError(t, service.RequireFeature(user.ID, "admin", Services.PlanFeatureAnomalyDetection))
+	assert.NoError(t, service.RequireHistoryRange(user.ID, "user", 24*time.Hour))
+	assert.Error(t, service.RequireHistoryRange(user.ID, "user", 48*time.Hour))
+
+	engine := gin.New()
+	engine.GET("/anomaly", func(c *gin.Context) {
+		c.Set("user_id", fmt.Sprintf("%d", user.ID))
+		c.Set("user_role", "user")
+	}, Middleware.NewPlanFeatureMiddleware(service).Require(Services.PlanFeatureAnomalyDetection), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	request := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/anomaly", nil))
+		return w
+	}
+
+	w := request()
+	assert.Equal(t, http.StatusPaymentRequired, w.Code)
+	assert.Contains(t, w.Body.String(), "PLAN_FEATURE_REQUIRED")
+
+	_, err := service.Checkout(context.Background(), user.ID, "pro")
+	require.NoError(t, err)
+	require.NoError(t, deliver(t, service, subscriptionEvent("evt_1", "customer.subscription.created", "trialing", "price_pro", now), now))
+	assert.Equal(t, http.StatusOK, request().Code)
+	assert.NoError(t, service.RequireHistoryRange(user.ID, "user", 30*24*time.Hour))
+
+	// 扣款失败后 Stripe 取消订阅，高级功能不再可用
+	require.NoError(t, deliver(t, service, subscriptionEvent("evt_2", "customer.subscription.updated", "unpaid", "price_pro", now.Add(time.Second)), now))
+	assert.Equal(t, http.StatusPaymentRequired, request().Code)
+}
+
+// fakeStripe 记录调用参数的 Stripe 客户端
+type fakeStripe struct {
+	proration     string
+	prorationDate time.Time
+}
+
+func (f *fakeStripe) CreateCustomer(ctx context.Context, email string, userID uint) (string, error) {
+	return "cus_1", nil
+}
+
+func (f *fakeStripe) CreateCheckoutSession(ctx context.Context, customerID, priceID string, userID uint) (string, error) {
+	return "https://checkout.stripe.test/" + priceID, nil
+}
+
+func (f *fakeStripe) UpdateSubscriptionPrice(ctx context.Context, subscriptionID, itemID, priceID, proration string, prorationDate time.Time) (*Services.StripeSubscription, error) {
+	f.proration, f.prorationDate = proration, prorationDate
+	return &Services.StripeSubscription{ID: subscriptionID, CustomerID: "cus_1", Status: "active", ItemID: itemID, PriceID: priceID}, nil
+}
+
+func (f *fakeStripe) PreviewSubscriptionPrice(ctx context.Context, customerID, subscriptionID, itemID, priceID, proration string, prorationDate time.Time) (*Services.BillingProrationPreview, error) {
+	f.proration, f.prorationDate = proration, prorationDate
+	return &Services.BillingProrationPreview{Currency: "usd", AmountDue: 1500, ProrationAmount: 500, ProrationDate: prorationDate}, nil
+}
+
+func (f *fakeStripe) CancelSubscription(ctx context.Context, subscriptionID string) (*Services.StripeSubscription, error) {
+	return &Services.StripeSubscription{ID: subscriptionID, CustomerID: "cus_1", Status: "active", ItemID: "si_1", PriceID: "price_pro", CancelAtPeriodEnd: true}, nil
+}
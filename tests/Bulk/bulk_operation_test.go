@@ -0,0 +1,337 @@
+package Bulk
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Utils"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const importCSV = `username,email,password,role,status
+bob,Bob@Example.com,Str0ng!Passw0rd,,
+alice,alice2@example.com,Str0ng!Passw0rd,user,1
+dave,not-an-email,Str0ng!Passw0rd,user,
+erin,bob@example.com,weak,superuser,
+carol,carol@example.com,,admin,disabled
+`
+
+func setupBulkDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "bulk.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.User{}, &Models.BulkJob{}, &Models.BulkJobError{}))
+	return db
+}
+
+// newBulkService 创建单协程、每2行保存一次进度的批量操作服务
+func newBulkService(db *gorm.DB) *Services.BulkOperationService {
+	config := &Config.BulkConfig{Workers: 1, QueueSize: 10, MaxRows: 100, MaxUploadSize: 1 << 20, ProgressEvery: 2}
+	return Services.NewBulkOperationService(db, config)
+}
+
+func createUser(t *testing.T, db *gorm.DB, username, role string) *Models.User {
+	user := &Models.User{Username: username, Email: username + "@example.com", Password: "x", Role: role, Status: 1}
+	require.NoError(t, db.Create(user).Error)
+	return user
+}
+
+// waitJob 等待任务执行结束
+func waitJob(t *testing.T, service *Services.BulkOperationService, id uint) *Models.BulkJob {
+	var job *Models.BulkJob
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = service.GetJob(id)
+		require.NoError(t, err)
+		return job.Status == Models.BulkJobCompleted || job.Status == Models.BulkJobFailed
+	}, 10*time.Second, 20*time.Millisecond)
+	return job
+}
+
+// errorFields 返回错误明细的 "行号:字段" 列表
+func errorFields(t *testing.T, service *Services.BulkOperationService, id uint) []string {
+	rowErrors, _, err := service.ListJobErrors(id, 1, 100)
+	require.NoError(t, err)
+	fields := make([]string, 0, len(rowErrors))
+	for _, rowError := range rowErrors {
+		fields = append(fields, rowError.Identifier+":"+rowError.Field)
+	}
+	return fields
+}
+
+func TestBulkUserImportDryRunAndImport(t *testing.T) {
+	db := setupBulkDB(t)
+	admin := createUser(t, db, "admin", "admin")
+	createUser(t, db, "alice", "user")
+	service := newBulkService(db)
+	service.Start()
+	defer service.Stop()
+
+	rows, err := service.ParseUserImport("csv", strings.NewReader("\xef\xbb\xbf"+importCSV))
+	require.NoError(t, err)
+	require.Len(t, rows, 5)
+	assert.Equal(t, 2, rows[0].Row, "行号包含表头行")
+	require.NotNil(t, rows[4].Status)
+	assert.Equal(t, 0, *rows[4].Status)
+
+	// 只校验：报告每行的错误，不写入用户
+	job, err := service.SubmitUserImport(rows, true, admin.ID)
+	require.NoError(t, err)
+	job = waitJob(t, service, job.ID)
+	assert.Equal(t, Models.BulkJobCompleted, job.Status)
+	assert.Equal(t, 5, job.Processed)
+	assert.Equal(t, 2, job.Succeeded)
+	assert.Equal(t, 3, job.Failed)
+	assert.Equal(t, 100.0, job.Progress())
+	assert.Equal(t, []string{"alice:username", "dave:email", "erin:email", "erin:password", "erin:role"}, errorFields(t, service, job.ID))
+	var count int64
+	db.Model(&Models.User{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+
+	rowErrors, _, err := service.ListJobErrors(job.ID, 1, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 3, rowErrors[0].Row)
+	assert.Equal(t, "用户名已存在", rowErrors[0].Message)
+	assert.Equal(t, "邮箱与第2行重复", rowErrors[2].Message)
+
+	// 正式导入
+	job, err = service.SubmitUserImport(rows, false, admin.ID)
+	require.NoError(t, err)
+	job = waitJob(t, service, job.ID)
+	assert.Equal(t, 2, job.Succeeded)
+	assert.Equal(t, 3, job.Failed)
+
+	var stored Models.BulkJob
+	require.NoError(t, db.First(&stored, job.ID).Error)
+	assert.Empty(t, stored.Payload, "导入完成后清空明文密码")
+
+	var bob, carol Models.User
+	require.NoError(t, db.Where("username = ?", "bob").First(&bob).Error)
+	assert.Equal(t, "bob@example.com", bob.Email)
+	assert.Equal(t, "user", bob.Role)
+	assert.True(t, Utils.CheckPassword("Str0ng!Passw0rd", bob.Password))
+	assert.False(t, bob.MustChangePassword)
+
+	require.NoError(t, db.Where("username = ?", "carol").First(&carol).Error)
+	assert.Equal(t, "admin", carol.Role)
+	assert.Equal(t, 0, carol.Status)
+	assert.NotEmpty(t, carol.Password)
+	assert.True(t, carol.MustChangePassword, "未提供密码时要求修改密码")
+
+	_, err = service.ParseUserImport("csv", strings.NewReader("name,mail\nx,y\n"))
+	assert.Error(t, err)
+	_, err = service.ParseUserImport("xlsx", strings.NewReader(importCSV))
+	assert.ErrorIs(t, err, Services.ErrBulkImportFormat)
+	_, err = service.ParseUserImport("json", strings.NewReader(`{"users": []}`))
+	assert.ErrorIs(t, err, Services.ErrBulkJobEmpty)
+}
+
+func TestBulkRoleAssignmentAndStatusChange(t *testing.T) {
+	db := setupBulkDB(t)
+	admin := createUser(t, db, "admin", "admin")
+	first := createUser(t, db, "first", "user")
+	second := createUser(t, db, "second", "admin")
+	tokens := Services.NewTokenBlacklistService(nil)
+	service := newBulkService(db)
+	service.SetTokenBlacklistService(tokens)
+	service.Start()
+	defer service.Stop()
+
+	_, err := service.SubmitRoleAssignment([]uint{first.ID}, "root", admin.ID)
+	assert.ErrorIs(t, err, Services.ErrBulkInvalidRole)
+
+	// 重复的ID只处理一次，操作者自己和不存在的用户记录错误
+	job, err := service.SubmitRoleAssignment([]uint{first.ID, second.ID, first.ID, admin.ID, 999}, "admin", admin.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, job.Total)
+	job = waitJob(t, service, job.ID)
+	assert.Equal(t, 2, job.Succeeded)
+	assert.Equal(t, 2, job.Failed)
+	assert.Equal(t, []string{"1:user_id", "999:user_id"}, errorFields(t, service, job.ID))
+
+	var user Models.User
+	require.NoError(t, db.First(&user, first.ID).Error)
+	assert.Equal(t, "admin", user.Role)
+	assert.True(t, tokens.IsUserTokenRevoked(first.ID, time.Now().Add(-time.Minute)), "角色变更后旧token失效")
+	assert.False(t, tokens.IsUserTokenRevoked(admin.ID, time.Now().Add(-time.Minute)))
+
+	job, err = service.SubmitStatusChange([]uint{second.ID}, 0, admin.ID)
+	require.NoError(t, err)
+	job = waitJob(t, service, job.ID)
+	assert.Equal(t, 1, job.Succeeded)
+	var disabled Models.User
+	require.NoError(t, db.First(&disabled, second.ID).Error)
+	assert.Equal(t, 0, disabled.Status)
+	assert.True(t, tokens.IsUserTokenRevoked(second.ID, time.Now().Add(-time.Minute)))
+
+	job, err = service.SubmitStatusChange([]uint{second.ID}, 1, admin.ID)
+	require.NoError(t, err)
+	waitJob(t, service, job.ID)
+	var enabled Models.User
+	require.NoError(t, db.First(&enabled, second.ID).Error)
+	assert.Equal(t, 1, enabled.Status)
+
+	_, err = service.SubmitStatusChange([]uint{second.ID}, 2, admin.ID)
+	assert.ErrorIs(t, err, Services.ErrBulkInvalidStatus)
+}
+
+func TestBulkJobResumesFromSavedProgress(t *testing.T) {
+	db := setupBulkDB(t)
+	admin := createUser(t, db, "admin", "admin")
+	service := newBulkService(db)
+
+	rows, err := service.ParseUserImport("json", strings.NewReader(`[
+		{"username": "user1", "email": "user1@example.com", "password": "Str0ng!Passw0rd"},
+		{"username": "user2", "email": "user2@example.com", "password": "Str0ng!Passw0rd"},
+		{"username": "user3", "email": "user1@example.com", "password": "Str0ng!Passw0rd"}
+	]`))
+	require.NoError(t, err)
+
+	// 服务未启动时任务保留在数据库中；模拟上次执行到第1行后中断
+	job, err := service.SubmitUserImport(rows, false, admin.ID)
+	require.NoError(t, err)
+	require.NoError(t, db.Model(job).Updates(map[string]interface{}{
+		"status": Models.BulkJobRunning, "processed": 1, "succeeded": 1,
+	}).Error)
+
+	service.Start()
+	defer service.Stop()
+	job = waitJob(t, service, job.ID)
+	assert.Equal(t, 3, job.Processed)
+	assert.Equal(t, 2, job.Succeeded)
+	assert.Equal(t, 1, job.Failed)
+	assert.Equal(t, []string{"user3:email"}, errorFields(t, service, job.ID), "已处理的行仍参与文件内重复检查")
+
+	var usernames []string
+	db.Model(&Models.User{}).Where("username LIKE ?", "user%").Order("username").Pluck("username", &usernames)
+	assert.Equal(t, []string{"user2"}, usernames, "已处理的行不重复执行")
+}
+
+func TestBulkControllerImportAndProgress(t *testing.T) {
+	db := setupBulkDB(t)
+	admin := createUser(t, db, "admin", "admin")
+	createUser(t, db, "alice", "user")
+	service := newBulkService(db)
+	service.Start()
+	defer service.Stop()
+
+	gin.SetMode(gin.TestMode)
+	controller := Controllers.NewBulkController(service)
+	router := gin.New()
+	router.Use(func(ctx *gin.Context) {
+		ctx.Set("user_id", "1")
+		ctx.Set("user_role", "admin")
+	})
+	router.POST("/api/v1/admin/bulk/users/import", controller.ImportUsers)
+	router.POST("/api/v1/admin/bulk/users/disable", controller.DisableUsers)
+	router.GET("/api/v1/admin/bulk/jobs/:id", controller.GetJob)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "team.csv")
+	require.NoError(t, err)
+	part.Write([]byte(importCSV))
+	require.NoError(t, writer.Close())
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/bulk/users/import?dry_run=true", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var submitted struct {
+		Data Models.BulkJob `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &submitted))
+	assert.True(t, submitted.Data.DryRun)
+	assert.Equal(t, admin.ID, submitted.Data.CreatedBy)
+	waitJob(t, service, submitted.Data.ID)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/bulk/jobs/"+jsonNumber(submitted.Data.ID), nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var progress struct {
+		Data struct {
+			Job      Models.BulkJob `json:"job"`
+			Progress float64        `json:"progress"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &progress))
+	assert.Equal(t, 100.0, progress.Data.Progress)
+	assert.Equal(t, 3, progress.Data.Job.Failed)
+
+	// JSON请求体导入
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/admin/bulk/users/import", strings.NewReader(`{"users": [{"username": "zed", "email": "zed@example.com"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/admin/bulk/users/import", strings.NewReader("not csv or json"))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/admin/bulk/users/disable", strings.NewReader(`{"user_ids": []}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/bulk/jobs/999", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func jsonNumber(id uint) string {
+	data, _ := json.Marshal(id)
+	return string(data)
+}
+
+func TestBulkUserDeletion(t *testing.T) {
+	db := setupBulkDB(t)
+	require.NoError(t, db.AutoMigrate(&Models.Post{}, &Models.ApiKey{}))
+	admin := createUser(t, db, "admin", "admin")
+	other := createUser(t, db, "other-admin", "admin")
+	first := createUser(t, db, "first", "user")
+	author := createUser(t, db, "author", "user")
+	require.NoError(t, db.Create(&Models.ApiKey{UserID: first.ID, Name: "ci", KeyHash: "h", Prefix: "ak_1"}).Error)
+	require.NoError(t, db.Create(&Models.Post{Title: "hello", UserID: author.ID}).Error)
+	tokens := Services.NewTokenBlacklistService(nil)
+	service := newBulkService(db)
+	service.SetTokenBlacklistService(tokens)
+	service.Start()
+	defer service.Stop()
+
+	// 操作者自己、管理员、有文章的用户和不存在的用户记录错误
+	job, err := service.SubmitUserDeletion([]uint{first.ID, admin.ID, other.ID, author.ID, 999}, admin.ID)
+	require.NoError(t, err)
+	assert.Equal(t, Models.BulkJobUserDelete, job.Type)
+	job = waitJob(t, service, job.ID)
+	assert.Equal(t, Models.BulkJobCompleted, job.Status)
+	assert.Equal(t, 1, job.Succeeded)
+	assert.Equal(t, 4, job.Failed)
+
+	assert.ErrorIs(t, db.First(&Models.User{}, first.ID).Error, gorm.ErrRecordNotFound)
+	var keys int64
+	require.NoError(t, db.Model(&Models.ApiKey{}).Where("user_id = ?", first.ID).Count(&keys).Error)
+	assert.Zero(t, keys, "API密钥随用户进入回收站")
+	assert.True(t, tokens.IsUserTokenRevoked(first.ID, time.Now().Add(-time.Minute)))
+	require.NoError(t, db.First(&Models.User{}, author.ID).Error)
+}
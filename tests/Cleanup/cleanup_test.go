@@ -0,0 +1,236 @@
+package Cleanup
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Services"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+type cleanupRecord struct {
+	ID        uint
+	Expired   bool
+	DeletedAt gorm.DeletedAt
+}
+
+func setupDB(t *testing.T, expired, kept int) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "cleanup.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&cleanupRecord{}))
+	for i := 0; i < expired+kept; i++ {
+		require.NoError(t, db.Create(&cleanupRecord{Expired: i < expired}).Error)
+	}
+	return db
+}
+
+func newScheduler(batchSize int) *Services.CleanupScheduler {
+	return Services.NewCleanupScheduler(&Config.CleanupConfig{
+		Enabled:        true,
+		Interval:       time.Hour,
+		BatchSize:      batchSize,
+		MaxRunDuration: time.Minute,
+	})
+}
+
+func remaining(t *testing.T, db *gorm.DB) int64 {
+	var count int64
+	require.NoError(t, db.Unscoped().Model(&cleanupRecord{}).Count(&count).Error)
+	return count
+}
+
+func TestBatchDeleteReportsProgress(t *testing.T) {
+	db := setupDB(t, 5, 2)
+	scheduler := newScheduler(2)
+	scheduler.Register("records", "清理测试记录", 0, func(ctx context.Context) (int64, error) {
+		return Services.BatchDelete(ctx, db.Unscoped().Where("expired = ?", true), &cleanupRecord{})
+	})
+
+	status, err := scheduler.Run(context.Background(), "records")
+	require.NoError(t, err)
+	assert.Equal(t, Services.CleanupResultSuccess, status.LastResult)
+	assert.EqualValues(t, 5, status.RowsDeleted)
+	assert.EqualValues(t, 3, status.Batches)
+	assert.Equal(t, "1h0m0s", status.Interval)
+	assert.EqualValues(t, 2, remaining(t, db), "未过期的记录保留，Unscoped 时彻底删除")
+
+	// 再次运行没有可删除的记录，累计删除数不变
+	status, err = scheduler.Run(context.Background(), "records")
+	require.NoError(t, err)
+	assert.Zero(t, status.RowsDeleted)
+	assert.EqualValues(t, 5, status.TotalRowsDeleted)
+	assert.EqualValues(t, 2, status.Runs)
+
+	_, err = scheduler.Run(context.Background(), "missing")
+	assert.ErrorIs(t, err, Services.ErrCleanupJobNotFound)
+}
+
+func TestBatchDeleteStopsWhenCancelled(t *testing.T) {
+	db := setupDB(t, 6, 0)
+	scheduler := Services.NewCleanupScheduler(&Config.CleanupConfig{
+		Enabled:    true,
+		Interval:   time.Hour,
+		BatchSize:  2,
+		BatchPause: time.Hour,
+	})
+	scheduler.Register("records", "清理测试记录", 0, func(ctx context.Context) (int64, error) {
+		return Services.BatchDelete(ctx, db.Where("expired = ?", true), &cleanupRecord{})
+	})
+
+	_, err := scheduler.Trigger("records")
+	require.NoError(t, err)
+	// 第一批删除后在批次间暂停，运行中返回当前进度
+	require.Eventually(t, func() bool {
+		status, err := scheduler.Job("records")
+		return err == nil && status.Running && status.RowsDeleted == 2 && status.Batches == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	_, err = scheduler.Trigger("records")
+	assert.ErrorIs(t, err, Services.ErrCleanupJobRunning)
+
+	require.NoError(t, scheduler.Cancel("records"))
+	scheduler.Wait()
+	status, err := scheduler.Job("records")
+	require.NoError(t, err)
+	assert.False(t, status.Running)
+	assert.Equal(t, Services.CleanupResultCancelled, status.LastResult)
+	assert.Zero(t, status.Failures)
+	assert.EqualValues(t, 2, status.RowsDeleted)
+	var count int64
+	require.NoError(t, db.Model(&cleanupRecord{}).Count(&count).Error)
+	assert.EqualValues(t, 4, count, "取消后停止删除，已删除的批次不回滚")
+	assert.ErrorIs(t, scheduler.Cancel("records"), Services.ErrCleanupJobNotRunning)
+}
+
+func TestCleanupJobTimeoutAndFailure(t *testing.T) {
+	scheduler := Services.NewCleanupScheduler(&Config.CleanupConfig{
+		Enabled:        true,
+		Interval:       time.Hour,
+		BatchSize:      10,
+		MaxRunDuration: 20 * time.Millisecond,
+	})
+	scheduler.Register("slow", "超时任务", time.Minute, func(ctx context.Context) (int64, error) {
+		<-ctx.Done()
+		return 3, ctx.Err()
+	})
+	scheduler.Register("broken", "失败任务", 0, func(ctx context.Context) (int64, error) {
+		return 0, errors.New("表不存在")
+	})
+	scheduler.Register("panics", "异常任务", 0, func(ctx context.Context) (int64, error) {
+		panic("boom")
+	})
+
+	statuses := scheduler.RunAll(context.Background())
+	require.Len(t, statuses, 3)
+	assert.Equal(t, Services.CleanupResultTimeout, statuses[0].LastResult)
+	assert.EqualValues(t, 3, statuses[0].RowsDeleted)
+	assert.Equal(t, "1m0s", statuses[0].Interval)
+	assert.Equal(t, Services.CleanupResultFailed, statuses[1].LastResult)
+	assert.Equal(t, "表不存在", statuses[1].LastError)
+	assert.Equal(t, Services.CleanupResultFailed, statuses[2].LastResult)
+	assert.Contains(t, statuses[2].LastError, "boom")
+
+	values, err := scheduler.Collector().Collect(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "cleanup", scheduler.Collector().Name())
+	assert.Equal(t, 3.0, values["cleanup_jobs"])
+	assert.Equal(t, 0.0, values["cleanup_running_jobs"])
+	assert.Equal(t, 3.0, values["cleanup_rows_deleted_total"])
+	assert.Equal(t, 2.0, values["cleanup_failures_total"])
+	assert.Equal(t, 3.0, values["cleanup_slow_last_rows_deleted"])
+	assert.Equal(t, 1.0, values["cleanup_broken_failures_total"])
+}
+
+func TestCleanupSchedulerRunsDueJobs(t *testing.T) {
+	db := setupDB(t, 3, 0)
+	scheduler := newScheduler(100)
+	scheduler.Register("records", "清理测试记录", 0, func(ctx context.Context) (int64, error) {
+		return Services.BatchDelete(ctx, db.Where("expired = ?", true), &cleanupRecord{})
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	scheduler.Start(ctx)
+	require.Eventually(t, func() bool {
+		status, err := scheduler.Job("records")
+		return err == nil && status.Runs == 1 && !status.Running
+	}, 5*time.Second, 10*time.Millisecond, "启动时先运行一次")
+	cancel()
+	scheduler.Wait()
+
+	var count int64
+	require.NoError(t, db.Model(&cleanupRecord{}).Count(&count).Error)
+	assert.Zero(t, count)
+	assert.EqualValues(t, 3, remaining(t, db), "未使用 Unscoped 时软删除")
+
+	// 未启用自动清理时不启动
+	disabled := Services.NewCleanupScheduler(&Config.CleanupConfig{Interval: time.Hour, BatchSize: 10})
+	disabled.Register("records", "清理测试记录", 0, func(ctx context.Context) (int64, error) {
+		t.Error("未启用时不应运行")
+		return 0, nil
+	})
+	disabled.Start(context.Background())
+	disabled.Wait()
+}
+
+func TestCleanupEndpoints(t *testing.T) {
+	scheduler := newScheduler(10)
+	release := make(chan struct{})
+	scheduler.Register("records", "清理测试记录", 0, func(ctx context.Context) (int64, error) {
+		select {
+		case <-release:
+			return 1, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	})
+	controller := Controllers.NewCleanupController(scheduler)
+	engine := gin.New()
+	engine.GET("/cleanup", controller.GetCleanupJobs)
+	engine.GET("/cleanup/:job", controller.GetCleanupJob)
+	engine.POST("/cleanup/:job/run", controller.RunCleanupJob)
+	engine.POST("/cleanup/:job/cancel", controller.CancelCleanupJob)
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/cleanup/missing").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/cleanup/missing/run").Code)
+	assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/cleanup/records/cancel").Code)
+
+	w := do(http.MethodPost, "/cleanup/records/run")
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/cleanup/records/run").Code)
+	close(release)
+	scheduler.Wait()
+
+	w = do(http.MethodGet, "/cleanup")
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data []Services.CleanupJobStatus `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, "records", resp.Data[0].Name)
+	assert.Equal(t, Services.CleanupResultSuccess, resp.Data[0].LastResult)
+	assert.EqualValues(t, 1, resp.Data[0].TotalRowsDeleted)
+}
@@ -0,0 +1,193 @@
+package Comments
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Testing"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func setupDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "comments.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.User{}, &Models.Comment{}, &Models.AuditLog{}, &Models.Notification{},
+		&Models.SecurityEvent{}, &Models.MonitoringReport{}))
+	return db
+}
+
+func mentionNotifications(t *testing.T, db *gorm.DB, userID uint) int64 {
+	var count int64
+	require.NoError(t, db.Model(&Models.Notification{}).
+		Where("user_id = ? AND type = ?", userID, Models.NotificationTypeMention).Count(&count).Error)
+	return count
+}
+
+func TestCommentThreadsAndMentions(t *testing.T) {
+	db := setupDB(t)
+	factory := Testing.NewFactory(t, db)
+	author := factory.User()
+	alice := factory.User()
+	bob := factory.User()
+	disabled := factory.User()
+	require.NoError(t, db.Model(disabled).Update("status", 0).Error)
+	event := Models.SecurityEvent{EventType: "brute_force", EventLevel: "high"}
+	require.NoError(t, db.Create(&event).Error)
+	resourceID := fmt.Sprint(event.ID)
+
+	service := Services.NewCommentService(db)
+	actor := Services.CommentActor{UserID: author.ID}
+
+	_, err := service.Create(actor, "post", resourceID, nil, "内容")
+	assert.ErrorIs(t, err, Services.ErrInvalidCommentResource)
+	_, err = service.Create(actor, Models.CommentResourceSecurityEvent, "999", nil, "内容")
+	assert.ErrorIs(t, err, Services.ErrCommentResourceNotFound)
+	_, err = service.Create(actor, Models.CommentResourceSecurityEvent, resourceID, nil, "   ")
+	assert.ErrorIs(t, err, Services.ErrInvalidComment)
+
+	// 邮箱地址、作者自己和已禁用的用户不算提及
+	content := fmt.Sprintf("@%s 请看一下，抄送 @%s @%s，联系 ops@%s.com", alice.Username, author.Username, disabled.Username, bob.Username)
+	root, err := service.Create(actor, Models.CommentResourceSecurityEvent, resourceID, nil, content)
+	require.NoError(t, err)
+	assert.Equal(t, alice.Username, root.Mentions)
+	assert.Equal(t, author.Username, root.Username)
+	assert.Equal(t, int64(1), mentionNotifications(t, db, alice.ID))
+	assert.Equal(t, int64(0), mentionNotifications(t, db, bob.ID))
+
+	reply, err := service.Create(Services.CommentActor{UserID: alice.ID}, Models.CommentResourceSecurityEvent, resourceID, &root.ID, "来源IP已封禁")
+	require.NoError(t, err)
+	// 回复其他回复时归到顶层评论下
+	nested, err := service.Create(actor, Models.CommentResourceSecurityEvent, resourceID, &reply.ID, "收到")
+	require.NoError(t, err)
+	require.NotNil(t, nested.ParentID)
+	assert.Equal(t, root.ID, *nested.ParentID)
+	_, err = service.Create(actor, Models.CommentResourceSecurityEvent, "42", &root.ID, "回复其他资源的评论")
+	assert.ErrorIs(t, err, Services.ErrCommentResourceNotFound)
+
+	// 只有作者可以编辑，编辑时只通知新增提及的用户
+	_, err = service.Update(root.ID, Services.CommentActor{UserID: alice.ID}, "改写")
+	assert.ErrorIs(t, err, Services.ErrCommentForbidden)
+	updated, err := service.Update(root.ID, actor, fmt.Sprintf("@%s @%s 请看一下", alice.Username, bob.Username))
+	require.NoError(t, err)
+	assert.NotNil(t, updated.EditedAt)
+	assert.Equal(t, alice.Username+","+bob.Username, updated.Mentions)
+	assert.Equal(t, int64(1), mentionNotifications(t, db, alice.ID))
+	assert.Equal(t, int64(1), mentionNotifications(t, db, bob.ID))
+
+	var audit Models.AuditLog
+	require.NoError(t, db.Where("action = ?", Models.AuditActionCommentUpdate).First(&audit).Error)
+	assert.Equal(t, root.ID, audit.ResourceID)
+	assert.Contains(t, audit.BeforeData, "请看一下，抄送")
+
+	// 普通用户不能删除他人评论，管理员可以，仍有回复的评论保留占位
+	assert.ErrorIs(t, service.Delete(root.ID, Services.CommentActor{UserID: bob.ID}), Services.ErrCommentForbidden)
+	admin := factory.Admin()
+	require.NoError(t, service.Delete(root.ID, Services.CommentActor{UserID: admin.ID, IsAdmin: true}))
+	_, err = service.Update(root.ID, actor, "已删除")
+	assert.ErrorIs(t, err, Services.ErrCommentNotFound)
+
+	var deleteAudit Models.AuditLog
+	require.NoError(t, db.Where("action = ?", Models.AuditActionCommentDelete).First(&deleteAudit).Error)
+	assert.Equal(t, admin.ID, deleteAudit.UserID)
+	assert.Equal(t, Models.AuditLevelWarning, deleteAudit.Level)
+
+	threads, err := service.List(actor, Models.CommentResourceSecurityEvent, resourceID)
+	require.NoError(t, err)
+	require.Len(t, threads, 1)
+	assert.True(t, threads[0].Deleted)
+	assert.Empty(t, threads[0].Content)
+	require.NotNil(t, threads[0].DeletedBy)
+	assert.Equal(t, admin.ID, *threads[0].DeletedBy)
+	require.Len(t, threads[0].Replies, 2)
+	assert.Equal(t, "来源IP已封禁", threads[0].Replies[0].Content)
+
+	// 回复全部删除后不再返回已删除的顶层评论
+	require.NoError(t, service.Delete(reply.ID, Services.CommentActor{UserID: alice.ID}))
+	require.NoError(t, service.Delete(nested.ID, actor))
+	threads, err = service.List(actor, Models.CommentResourceSecurityEvent, resourceID)
+	require.NoError(t, err)
+	assert.Empty(t, threads)
+}
+
+func TestReportCommentsRequireAdmin(t *testing.T) {
+	db := setupDB(t)
+	factory := Testing.NewFactory(t, db)
+	admin := factory.Admin()
+	otherAdmin := factory.Admin()
+	user := factory.User()
+	report := Models.MonitoringReport{Name: "周报", Type: "weekly", Template: "{}", CreatedBy: admin.ID}
+	require.NoError(t, db.Create(&report).Error)
+
+	service := Services.NewCommentService(db)
+	controller := Controllers.NewCommentController(service)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", c.GetHeader("X-Test-User"))
+		c.Set("user_role", c.GetHeader("X-Test-Role"))
+	})
+	router.GET("/api/v1/comments", controller.ListComments)
+	router.POST("/api/v1/comments", controller.CreateComment)
+	router.DELETE("/api/v1/comments/:id", controller.DeleteComment)
+
+	request := func(method, path string, body interface{}, user *Models.User) *httptest.ResponseRecorder {
+		var data []byte
+		if body != nil {
+			data, _ = json.Marshal(body)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-User", fmt.Sprint(user.ID))
+		req.Header.Set("X-Test-Role", user.Role)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 报告评论只通知管理员
+	body := map[string]interface{}{
+		"resource_type": "report",
+		"resource_id":   fmt.Sprint(report.ID),
+		"content":       fmt.Sprintf("@%s @%s 本周可用率下降", otherAdmin.Username, user.Username),
+	}
+	w := request(http.MethodPost, "/api/v1/comments", body, user)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = request(http.MethodPost, "/api/v1/comments", body, admin)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, int64(1), mentionNotifications(t, db, otherAdmin.ID))
+	assert.Equal(t, int64(0), mentionNotifications(t, db, user.ID))
+
+	path := fmt.Sprintf("/api/v1/comments?resource_type=report&resource_id=%d", report.ID)
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, path, nil, user).Code)
+	w = request(http.MethodGet, path, nil, otherAdmin)
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data []Services.CommentThread `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, admin.Username, resp.Data[0].Username)
+
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodGet, "/api/v1/comments?resource_type=post&resource_id=1", nil, admin).Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodDelete, "/api/v1/comments/99", nil, admin).Code)
+	body["resource_id"] = "999"
+	assert.Equal(t, http.StatusNotFound, request(http.MethodPost, "/api/v1/comments", body, admin).Code)
+}
@@ -0,0 +1,101 @@
+package Console
+
+import (
+	"cloud-platform-api/app/Testing/LoadTest"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// benchServer 模拟登录、指标和告警接口，delay 为指标和告警接口的处理耗时
+func benchServer(t *testing.T, delay *atomic.Int64) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		var input map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&input)
+		if input["password"] != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"success":false,"message":"用户名或密码错误"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"success":true,"message":"ok","data":{"token":"bench-token"}}`))
+	})
+	authorized := func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Duration(delay.Load()))
+		if r.Header.Get("Authorization") != "Bearer bench-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"success":true}`))
+	}
+	mux.HandleFunc("/api/v1/monitoring/metrics", authorized)
+	mux.HandleFunc("/api/v1/monitoring/alerts", authorized)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestBenchBaselineAndGate(t *testing.T) {
+	var delay atomic.Int64
+	server := benchServer(t, &delay)
+	baseline := filepath.Join(t.TempDir(), "baseline.json")
+	t.Setenv("CLOUDCTL_BENCH_PASSWORD", "secret")
+	args := []string{"bench", "--url", server.URL, "--username", "admin", "--requests", "20", "--concurrency", "2",
+		"--warmup", "0", "--baseline", baseline}
+
+	// 基线不存在时提示生成
+	app, _, errOut := newApp(nil)
+	assert.Equal(t, 1, app.Run(args))
+	assert.Contains(t, errOut.String(), "--update-baseline")
+
+	app, out, errOut := newApp(nil)
+	require.Equal(t, 0, app.Run(append(args, "--update-baseline")), errOut.String())
+	assert.Contains(t, out.String(), "已更新基线文件")
+	report, err := LoadTest.LoadReport(baseline)
+	require.NoError(t, err)
+	require.Len(t, report.Scenarios, 3)
+	assert.Equal(t, 20, report.Scenario("alerts").Requests)
+
+	// 同样的服务不视为回归
+	app, out, errOut = newApp(nil)
+	require.Equal(t, 0, app.Run(append(args, "--min-delta", "20ms")), errOut.String()+out.String())
+	assert.Contains(t, out.String(), "未发现性能回归")
+
+	// 指标和告警接口变慢后失败
+	delay.Store(int64(30 * time.Millisecond))
+	app, out, errOut = newApp(nil)
+	assert.Equal(t, 1, app.Run(append(args, "--min-delta", "10ms")))
+	assert.Contains(t, out.String(), "metrics: P95 由")
+	assert.Contains(t, out.String(), "alerts: P95 由")
+	assert.NotContains(t, out.String(), "login: P95")
+	assert.Contains(t, errOut.String(), "发现 2 项性能回归")
+}
+
+func TestBenchRejectsFailures(t *testing.T) {
+	var delay atomic.Int64
+	server := benchServer(t, &delay)
+	baseline := filepath.Join(t.TempDir(), "baseline.json")
+
+	app, _, errOut := newApp(nil)
+	assert.Equal(t, 1, app.Run([]string{"bench", "--url", server.URL, "--username", "admin", "--password", "wrong", "--baseline", baseline}))
+	assert.Contains(t, errOut.String(), "登录失败（状态码 401）: 用户名或密码错误")
+
+	app, _, errOut = newApp(nil)
+	assert.Equal(t, 1, app.Run([]string{"bench", "--url", server.URL, "--scenarios", "metrics,unknown", "--baseline", baseline}))
+	assert.Contains(t, errOut.String(), "未知的压测场景: unknown")
+
+	// 未登录时指标接口返回 401，计为失败
+	require.NoError(t, (&LoadTest.Report{}).WriteFile(baseline))
+	app, out, errOut := newApp(nil)
+	assert.Equal(t, 1, app.Run([]string{"bench", "--url", server.URL, "--scenarios", "login,metrics", "--requests", "5", "--baseline", baseline}))
+	assert.Contains(t, out.String(), "跳过登录场景")
+	assert.Contains(t, out.String(), "metrics: 请求失败率 100.00%")
+	assert.Contains(t, errOut.String(), "发现 1 项性能回归")
+}
@@ -0,0 +1,252 @@
+package Console
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Console"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Utils"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupConsoleDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "console.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.User{}, &Models.OutboxEvent{}))
+	return db
+}
+
+func newApp(db *gorm.DB) (*Console.Application, *bytes.Buffer, *bytes.Buffer) {
+	var out, errOut bytes.Buffer
+	app := Console.NewApplication(&out, &errOut)
+	if db != nil {
+		app.SetDB(db)
+	}
+	return app, &out, &errOut
+}
+
+func TestUsageAndUnknownCommands(t *testing.T) {
+	app, out, _ := newApp(nil)
+	assert.Equal(t, 2, app.Run(nil))
+	assert.Contains(t, out.String(), "migrate")
+	assert.Contains(t, out.String(), "logs")
+
+	app, out, _ = newApp(nil)
+	assert.Equal(t, 0, app.Run([]string{"user", "help"}))
+	assert.Contains(t, out.String(), "create-admin")
+
+	app, _, errOut := newApp(nil)
+	assert.Equal(t, 2, app.Run([]string{"user", "delete"}))
+	assert.Contains(t, errOut.String(), "未知命令")
+
+	app, _, _ = newApp(nil)
+	assert.Equal(t, 2, app.Run([]string{"migrate", "rollback", "--unknown"}))
+}
+
+func TestCreateAdminWithGeneratedPassword(t *testing.T) {
+	db := setupConsoleDB(t)
+	Services.SetDefaultEventBus(Services.NewEventBus(db, Config.EventBusConfig{Enabled: true}))
+	t.Cleanup(func() { Services.SetDefaultEventBus(nil) })
+	app, out, errOut := newApp(db)
+
+	code := app.Run([]string{"user", "create-admin", "--username", "ops", "--email", "ops@example.com"})
+	require.Equal(t, 0, code, errOut.String())
+
+	match := regexp.MustCompile(`初始密码: (\S+)`).FindStringSubmatch(out.String())
+	require.Len(t, match, 2)
+
+	var user Models.User
+	require.NoError(t, db.Where("username = ?", "ops").First(&user).Error)
+	assert.Equal(t, "admin", user.Role)
+	assert.Equal(t, 1, user.Status)
+	assert.True(t, user.MustChangePassword)
+	assert.True(t, Utils.CheckPassword(match[1], user.Password))
+
+	// 与后台创建用户一致，同一事务中写入用户创建事件
+	var event Models.OutboxEvent
+	require.NoError(t, db.Where("event_type = ?", Services.EventUserCreated).First(&event).Error)
+	assert.Contains(t, event.Payload, `"source":"admin"`)
+
+	// 用户名重复
+	app, _, errOut = newApp(db)
+	assert.Equal(t, 1, app.Run([]string{"user", "create-admin", "--username", "ops", "--email", "other@example.com"}))
+	assert.Contains(t, errOut.String(), "创建管理员失败")
+}
+
+func TestCreateAdminValidatesInput(t *testing.T) {
+	db := setupConsoleDB(t)
+
+	app, _, errOut := newApp(db)
+	assert.Equal(t, 1, app.Run([]string{"user", "create-admin", "--username", "ops"}))
+	assert.Contains(t, errOut.String(), "不能为空")
+
+	app, _, errOut = newApp(db)
+	assert.Equal(t, 1, app.Run([]string{"user", "create-admin", "--username", "ops", "--email", "ops@example.com", "--password", "weak"}))
+	assert.Contains(t, errOut.String(), "密码强度不足")
+
+	app, out, errOut := newApp(db)
+	require.Equal(t, 0, app.Run([]string{"user", "create-admin", "--username", "ops", "--email", "ops@example.com", "--password", "Str0ng!Passw0rd"}), errOut.String())
+	assert.NotContains(t, out.String(), "初始密码")
+
+	var user Models.User
+	require.NoError(t, db.Where("username = ?", "ops").First(&user).Error)
+	assert.False(t, user.MustChangePassword)
+	assert.True(t, Utils.CheckPassword("Str0ng!Passw0rd", user.Password))
+}
+
+func TestRotateJWTSecret(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(envFile, []byte("APP_NAME=demo\nJWT_SECRET=old-secret\n# 注释\nJWT_ISSUER=cloud\n"), 0640))
+
+	app, out, errOut := newApp(nil)
+	require.Equal(t, 0, app.Run([]string{"jwt", "rotate-secret", "--env-file", envFile}), errOut.String())
+	assert.NotContains(t, out.String(), "old-secret")
+
+	data, err := os.ReadFile(envFile)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, "APP_NAME=demo", lines[0])
+	assert.Equal(t, "# 注释", lines[2])
+	assert.Equal(t, "JWT_ISSUER=cloud", lines[3])
+
+	secret, ok := strings.CutPrefix(lines[1], "JWT_SECRET=")
+	require.True(t, ok)
+	assert.NotEqual(t, "old-secret", secret)
+	jwtConfig := Config.JWTConfig{Secret: secret, ExpireTime: 24}
+	assert.NoError(t, jwtConfig.Validate())
+
+	info, err := os.Stat(envFile)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	// --print 只输出，不修改文件
+	app, out, _ = newApp(nil)
+	require.Equal(t, 0, app.Run([]string{"jwt", "rotate-secret", "--env-file", envFile, "--print"}))
+	assert.True(t, strings.HasPrefix(out.String(), "JWT_SECRET="))
+	after, err := os.ReadFile(envFile)
+	require.NoError(t, err)
+	assert.Equal(t, data, after)
+}
+
+func TestWriteEnvValueAppendsToNewFile(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, Console.WriteEnvValue(envFile, "JWT_SECRET", "first"))
+	require.NoError(t, Console.WriteEnvValue(envFile, "OTHER", "value"))
+	require.NoError(t, Console.WriteEnvValue(envFile, "JWT_SECRET", "second"))
+
+	data, err := os.ReadFile(envFile)
+	require.NoError(t, err)
+	assert.Equal(t, "JWT_SECRET=second\nOTHER=value\n", string(data))
+
+	info, err := os.Stat(envFile)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestDestructiveCommandsRequireForce(t *testing.T) {
+	for _, args := range [][]string{
+		{"migrate", "reset"},
+		{"backup", "restore", "./backup.sql"},
+		{"cache", "flush"},
+	} {
+		app, _, errOut := newApp(nil)
+		assert.Equal(t, 1, app.Run(args), strings.Join(args, " "))
+		assert.Contains(t, errOut.String(), "--force", strings.Join(args, " "))
+	}
+}
+
+func TestConfigSnapshotAndDiff(t *testing.T) {
+	t.Setenv("JWT_SECRET", "console-test-secret-8f3a9c2e7b1d4f6a0e5c")
+	db := setupConsoleDB(t)
+	require.NoError(t, db.AutoMigrate(&Models.ConfigSnapshot{}))
+
+	app, out, errOut := newApp(db)
+	require.Equal(t, 0, app.Run([]string{"config", "snapshot", "--env", "ci", "--label", "baseline"}), errOut.String())
+	assert.Contains(t, out.String(), "ci v1")
+
+	app, out, errOut = newApp(db)
+	require.Equal(t, 0, app.Run([]string{"config", "diff", "1"}), errOut.String())
+	assert.Contains(t, out.String(), "配置一致")
+
+	app, out, _ = newApp(db)
+	require.Equal(t, 0, app.Run([]string{"config", "show"}))
+	assert.Contains(t, out.String(), "server.port = ")
+	assert.Contains(t, out.String(), "database.password = ")
+
+	app, _, errOut = newApp(db)
+	assert.Equal(t, 1, app.Run([]string{"config", "diff", "1", "99"}))
+	assert.Contains(t, errOut.String(), "配置快照不存在")
+}
+
+func TestMigratePlanAndApprove(t *testing.T) {
+	t.Setenv("JWT_SECRET", "console-test-secret-8f3a9c2e7b1d4f6a0e5c")
+	db := setupConsoleDB(t)
+	planFile := filepath.Join(t.TempDir(), "plan.json")
+
+	app, out, errOut := newApp(db)
+	require.Equal(t, 0, app.Run([]string{"migrate", "up", "--plan", "--out", planFile}), errOut.String())
+	hash := regexp.MustCompile(`哈希: ([0-9a-f]{64})`).FindStringSubmatch(out.String())
+	require.Len(t, hash, 2, out.String())
+	data, err := os.ReadFile(planFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), hash[1])
+
+	var ran int64
+	require.NoError(t, db.Table("migrations").Count(&ran).Error)
+	assert.Zero(t, ran, "--plan 不执行迁移")
+
+	app, _, errOut = newApp(db)
+	assert.Equal(t, 1, app.Run([]string{"migrate", "approve", "--hash", hash[1]}))
+	assert.Contains(t, errOut.String(), "--by")
+
+	app, _, errOut = newApp(db)
+	assert.Equal(t, 1, app.Run([]string{"migrate", "approve", "--hash", strings.Repeat("0", 64), "--by", "ops"}))
+	assert.Contains(t, errOut.String(), "不一致")
+
+	app, out, errOut = newApp(db)
+	require.Equal(t, 0, app.Run([]string{"migrate", "approve", "--hash", hash[1], "--by", "ops"}), errOut.String())
+	assert.Contains(t, out.String(), "已审批")
+}
+
+func TestBootstrapDemo(t *testing.T) {
+	t.Setenv("JWT_SECRET", "console-test-secret-8f3a9c2e7b1d4f6a0e5c")
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "demo.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	app, out, errOut := newApp(db)
+	require.Equal(t, 0, app.Run([]string{"bootstrap-demo", "--demo-password", "Demo-Passw0rd!"}), errOut.String())
+	assert.Contains(t, out.String(), "数据库迁移完成")
+	assert.Contains(t, out.String(), "demo-operator")
+	assert.Contains(t, out.String(), "Demo-Passw0rd!")
+	assert.Contains(t, out.String(), "管理员首次登录后需要修改密码")
+
+	var admin Models.User
+	require.NoError(t, db.Where("username = ?", "admin").First(&admin).Error)
+	assert.True(t, admin.MustChangePassword, "生成的管理员密码需要首次登录后修改")
+
+	// 重复执行时跳过已存在的数据
+	app, out, errOut = newApp(db)
+	require.Equal(t, 0, app.Run([]string{"seed", "--env", "demo", "--only", "demo_tenant"}), errOut.String())
+	assert.Contains(t, out.String(), "已存在，跳过")
+	assert.NotContains(t, out.String(), "创建的账号")
+
+	app, out, _ = newApp(db)
+	require.Equal(t, 0, app.Run([]string{"seed", "--env", "production", "--list"}))
+	assert.Equal(t, "admin_user\ndefault_roles\nalert_rules\n", out.String())
+}
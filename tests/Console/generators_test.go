@@ -0,0 +1,146 @@
+package Console
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMigrationFile = `package Migrations
+
+type MigrationManager struct{}
+
+func (m *MigrationManager) GetMigrationFiles() []Migration {
+	return []Migration{
+		&CreateUsersTable{},
+	}
+}
+
+// CreateUsersTable 用户表
+func (m *CreateUsersTable) GetName() string {
+	return "2024_01_01_000007_create_users_table"
+}
+`
+
+const testRoutesFile = "package Routes\r\n\r\nfunc RegisterRoutes() {\r\n\tif db := Database.GetDB(); db != nil {\r\n\t\t// cloudctl:routes\r\n\t}\r\n}\r\n"
+
+// setupProject 创建只包含迁移列表和路由注册文件的项目目录
+func setupProject(t *testing.T) string {
+	root := t.TempDir()
+	writeProjectFile(t, root, "app/Database/Migrations/Migration.go", testMigrationFile)
+	writeProjectFile(t, root, "app/Http/Routes/routes.go", testRoutesFile)
+	return root
+}
+
+func writeProjectFile(t *testing.T, root, path, content string) {
+	fullPath := filepath.Join(root, path)
+	require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0755))
+	require.NoError(t, os.WriteFile(fullPath, []byte(content), 0644))
+}
+
+func readProjectFile(t *testing.T, root, path string) string {
+	data, err := os.ReadFile(filepath.Join(root, path))
+	require.NoError(t, err)
+	return string(data)
+}
+
+// assertParses 生成的文件必须是合法的Go源码
+func assertParses(t *testing.T, root string, paths ...string) {
+	for _, path := range paths {
+		_, err := parser.ParseFile(token.NewFileSet(), path, readProjectFile(t, root, path), parser.ParseComments)
+		assert.NoError(t, err, path)
+	}
+}
+
+func TestMakeModelWithMigration(t *testing.T) {
+	root := setupProject(t)
+
+	app, out, errOut := newApp(nil)
+	require.Equal(t, 0, app.Run([]string{"make:model", "article_tag", "-m", "--dir", root, "--label", "文章标签"}), errOut.String())
+	assert.Contains(t, out.String(), "app/Models/ArticleTag.go")
+	assertParses(t, root, "app/Models/ArticleTag.go", "app/Database/Migrations/CreateArticleTagsTable.go")
+
+	model := readProjectFile(t, root, "app/Models/ArticleTag.go")
+	assert.Contains(t, model, "type ArticleTag struct")
+	assert.Contains(t, model, "// ArticleTag 文章标签")
+
+	migration := readProjectFile(t, root, "app/Database/Migrations/CreateArticleTagsTable.go")
+	assert.Contains(t, migration, `"2024_01_01_000008_create_article_tags_table"`)
+
+	migrations := readProjectFile(t, root, "app/Database/Migrations/Migration.go")
+	assert.Contains(t, migrations, "\t\t&CreateUsersTable{},\n\t\t&CreateArticleTagsTable{},\n\t}")
+
+	// 序号在已生成的迁移基础上递增
+	app, _, errOut = newApp(nil)
+	require.Equal(t, 0, app.Run([]string{"make:model", "--dir", root, "-m", "Category"}), errOut.String())
+	assert.Contains(t, readProjectFile(t, root, "app/Database/Migrations/CreateCategoriesTable.go"), "2024_01_01_000009_create_categories_table")
+	assert.Contains(t, readProjectFile(t, root, "app/Database/Migrations/Migration.go"), "&CreateArticleTagsTable{},\n\t\t&CreateCategoriesTable{},")
+}
+
+func TestMakeResourceControllerRegistersRoutes(t *testing.T) {
+	root := setupProject(t)
+
+	app, _, errOut := newApp(nil)
+	assert.Equal(t, 1, app.Run([]string{"make:controller", "ArticleTag", "--resource", "--dir", root}))
+	assert.Contains(t, errOut.String(), "make:model ArticleTag")
+
+	app, _, errOut = newApp(nil)
+	require.Equal(t, 0, app.Run([]string{"make:model", "ArticleTag", "--dir", root}), errOut.String())
+	app, _, errOut = newApp(nil)
+	require.Equal(t, 0, app.Run([]string{"make:controller", "ArticleTagController", "--resource", "--dir", root}), errOut.String())
+	assertParses(t, root,
+		"app/Services/ArticleTagService.go",
+		"app/Http/Controllers/ArticleTagController.go",
+		"app/Http/Routes/article_tag.go",
+	)
+	assert.Contains(t, readProjectFile(t, root, "app/Http/Routes/article_tag.go"), `"/api/v1/admin/article-tags"`)
+
+	call := "RegisterArticleTagRoutes(engine, storageManager, Controllers.NewArticleTagController(Services.NewArticleTagService(db)))"
+	routes := readProjectFile(t, root, "app/Http/Routes/routes.go")
+	assert.Contains(t, routes, "\t\t"+call+"\r\n\t\t// cloudctl:routes\r\n")
+	assert.NotContains(t, strings.ReplaceAll(routes, "\r\n", ""), "\n", "保持原有的CRLF换行")
+
+	// 已存在的文件不会被覆盖
+	app, _, errOut = newApp(nil)
+	assert.Equal(t, 1, app.Run([]string{"make:controller", "ArticleTag", "--resource", "--dir", root}))
+	assert.Contains(t, errOut.String(), "--force")
+
+	// 覆盖时路由只注册一次
+	app, _, errOut = newApp(nil)
+	require.Equal(t, 0, app.Run([]string{"make:controller", "ArticleTag", "--resource", "--force", "--dir", root}), errOut.String())
+	assert.Equal(t, 1, strings.Count(readProjectFile(t, root, "app/Http/Routes/routes.go"), call))
+}
+
+func TestMakeReadsNameFromInput(t *testing.T) {
+	root := setupProject(t)
+
+	app, out, errOut := newApp(nil)
+	app.SetInput(strings.NewReader("request-timing\n"))
+	require.Equal(t, 0, app.Run([]string{"make:middleware", "--dir", root}), errOut.String())
+	assert.Contains(t, out.String(), "中间件名称: ")
+	assertParses(t, root, "app/Http/Middleware/RequestTimingMiddleware.go")
+
+	app, _, _ = newApp(nil)
+	app.SetInput(strings.NewReader("\n"))
+	assert.Equal(t, 1, app.Run([]string{"make:service", "--dir", root}))
+}
+
+func TestMakePolicyForModel(t *testing.T) {
+	root := setupProject(t)
+
+	app, _, errOut := newApp(nil)
+	assert.Equal(t, 1, app.Run([]string{"make:policy", "Report", "--model", "Report", "--dir", root}))
+	assert.Contains(t, errOut.String(), "不存在")
+
+	writeProjectFile(t, root, "app/Models/Report.go", "package Models\n\ntype Report struct {\n\tID uint\n}\n")
+	app, _, errOut = newApp(nil)
+	require.Equal(t, 0, app.Run([]string{"make:policy", "ReportPolicy", "--model", "report", "--dir", root}), errOut.String())
+	assertParses(t, root, "app/Policies/ReportPolicy.go")
+	assert.Contains(t, readProjectFile(t, root, "app/Policies/ReportPolicy.go"), "*Models.Report")
+}
@@ -0,0 +1,220 @@
+package Contract
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Routes"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"cloud-platform-api/app/Testing"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func setupDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "contract.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&Models.User{}, &Models.AlertRule{}, &Models.Alert{}, &Models.MonitoringMetric{}, &Models.MetricSample{},
+		&Models.OutboxEvent{}, &Models.WebhookSubscription{}, &Models.WebhookDelivery{},
+	))
+	return db
+}
+
+// setupWebhookAPI 注册Webhook订阅路由，返回引擎、接口文档和测试数据工厂
+func setupWebhookAPI(t *testing.T) (*gin.Engine, *OpenAPI.Registry, *Testing.Factory) {
+	t.Setenv("JWT_SECRET", "test-jwt-secret-key-for-testing-only-32-chars")
+	Config.LoadConfig()
+
+	registry := OpenAPI.NewRegistry(OpenAPI.Info{Title: "测试", Version: "1.0.0"})
+	OpenAPI.SetDefaultRegistry(registry)
+	t.Cleanup(func() {
+		OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "Cloud Platform API", Version: "1.0.0"}))
+	})
+
+	db := setupDB(t)
+	service := Services.NewWebhookService(db, &Config.WebhookConfig{
+		Enabled:              true,
+		Workers:              1,
+		QueueSize:            10,
+		Timeout:              time.Second,
+		MaxAttempts:          3,
+		RetryBackoff:         time.Minute,
+		MaxBackoff:           time.Hour,
+		AllowPrivateNetworks: true,
+	})
+	storageManager := Storage.NewStorageManager(&Config.StorageConfig{BasePath: t.TempDir()})
+
+	engine := gin.New()
+	Routes.RegisterWebhookRoutes(engine, storageManager, Controllers.NewWebhookController(service))
+	return engine, registry, Testing.NewFactory(t, db)
+}
+
+func TestWebhookContract(t *testing.T) {
+	engine, registry, factory := setupWebhookAPI(t)
+	contract := Testing.NewContract(t, engine, registry)
+	admin := contract.WithToken(factory.Token(factory.Admin()))
+
+	created := admin.Do(http.MethodPost, "/api/v1/admin/webhooks", Services.WebhookSubscriptionInput{
+		Name:       "告警通知",
+		URL:        "https://hooks.example.com/alerts",
+		EventTypes: []string{"alert.*"},
+		Filter:     map[string]interface{}{"severity": []string{"critical"}},
+	}).AssertStatus(http.StatusCreated).AssertGolden("webhooks_create")
+	var secret Controllers.WebhookSecretResponse
+	created.Decode(&secret)
+	require.NotNil(t, secret.Subscription)
+	assert.NotEmpty(t, secret.Secret)
+
+	id := secret.Subscription.ID
+	admin.Do(http.MethodGet, "/api/v1/admin/webhooks?sort=-id&filter[status][eq]=active", nil).
+		AssertStatus(http.StatusOK).AssertGolden("webhooks_list")
+	admin.Do(http.MethodGet, fmt.Sprintf("/api/v1/admin/webhooks/%d", id), nil).
+		AssertStatus(http.StatusOK).AssertGolden("webhooks_show")
+	admin.Do(http.MethodPost, fmt.Sprintf("/api/v1/admin/webhooks/%d/pause", id), nil).AssertStatus(http.StatusOK)
+	admin.Do(http.MethodGet, fmt.Sprintf("/api/v1/admin/webhooks/%d/deliveries", id), nil).
+		AssertStatus(http.StatusOK).AssertGolden("webhooks_deliveries")
+	admin.Do(http.MethodGet, "/api/v1/admin/webhooks/event-types", nil).AssertStatus(http.StatusOK)
+
+	// 错误响应同样按文档校验
+	admin.Do(http.MethodGet, "/api/v1/admin/webhooks/999", nil).
+		AssertStatus(http.StatusNotFound).AssertGolden("error")
+	admin.Do(http.MethodPost, "/api/v1/admin/webhooks", `{"name":"缺少地址"}`).AssertStatus(http.StatusBadRequest)
+	contract.Do(http.MethodGet, "/api/v1/admin/webhooks", nil).AssertStatus(http.StatusUnauthorized)
+	contract.WithToken(factory.Token(factory.User())).
+		Do(http.MethodGet, "/api/v1/admin/webhooks", nil).AssertStatus(http.StatusForbidden)
+}
+
+// failureRecorder 记录契约不符的失败信息，不让外层测试失败
+type failureRecorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *failureRecorder) Helper() {}
+
+func (r *failureRecorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestContractDetectsDrift(t *testing.T) {
+	type documented struct {
+		ID   uint   `json:"id"`
+		Name string `json:"name"`
+	}
+	registry := OpenAPI.NewRegistry(OpenAPI.Info{Title: "测试", Version: "1.0.0"})
+	engine := gin.New()
+	api := registry.Group(engine.Group("/items"), "条目")
+	api.GET("/:id", OpenAPI.Route{Summary: "条目详情", Params: []OpenAPI.Param{OpenAPI.PathID("id", "条目ID")}, Response: documented{}}, func(c *gin.Context) {
+		if c.Param("id") == "2" {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "条目不存在"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "ok", "data": gin.H{"id": "1", "name": "条目", "extra": true}})
+	})
+	api.GET("/latest", OpenAPI.Route{Summary: "最新条目", Response: documented{}}, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "ok", "data": documented{ID: 1, Name: "条目"}})
+	})
+
+	recorder := &failureRecorder{TB: t}
+	contract := Testing.NewContract(recorder, engine, registry)
+
+	// 静态路由优先匹配，符合文档
+	response := contract.Do(http.MethodGet, "/items/latest", nil)
+	assert.Equal(t, "/items/latest", response.Route)
+	assert.Empty(t, recorder.failures)
+
+	contract.Do(http.MethodGet, "/items/1", nil)
+	require.Len(t, recorder.failures, 1)
+	assert.Contains(t, recorder.failures[0], "响应体.data.id 应为数字")
+	assert.Contains(t, recorder.failures[0], "响应体.data 包含未声明的字段 extra")
+
+	recorder.failures = nil
+	contract.Do(http.MethodGet, "/items/2", nil)
+	require.Len(t, recorder.failures, 1)
+	assert.Contains(t, recorder.failures[0], "响应状态码 404 未在接口文档中声明")
+
+	// 黄金文件记录结构，取值不同不影响比对，字段变化时失败
+	dir := t.TempDir()
+	recorder.failures = nil
+	Testing.AssertGoldenShape(recorder, dir, "item", []byte(`{"id":1,"name":"a","tags":["x"]}`))
+	require.Len(t, recorder.failures, 1)
+	assert.Contains(t, recorder.failures[0], "不存在")
+
+	recorder.failures = nil
+	Testing.AssertGoldenShape(recorder, dir, "item", []byte(`{"id":2,"name":"b","tags":[]}`))
+	assert.Empty(t, recorder.failures)
+	Testing.AssertGoldenShape(recorder, dir, "item", []byte(`{"id":2,"name":"b","tags":["x"],"owner":null}`))
+	require.Len(t, recorder.failures, 1)
+	assert.Contains(t, recorder.failures[0], "$.owner: 新增字段")
+
+	recorder.failures = nil
+	Testing.AssertGoldenShape(recorder, dir, "item", []byte(`{"id":"2","tags":[1]}`))
+	require.Len(t, recorder.failures, 1)
+	assert.Contains(t, recorder.failures[0], "$.id: 类型由 integer 变为 string")
+	assert.Contains(t, recorder.failures[0], "$.name: 字段缺失")
+	assert.Contains(t, recorder.failures[0], "$.tags[]: 类型由 string 变为 integer")
+}
+
+func TestJSONShape(t *testing.T) {
+	shape, err := Testing.JSONShape([]byte(`[{"id":1,"score":1},{"id":2,"score":1.5,"note":null},{"id":3,"note":"x"}]`))
+	require.NoError(t, err)
+	assert.Equal(t, "array", shape.Type)
+	items := shape.Items
+	assert.Equal(t, "integer", items.Properties["id"].Type)
+	assert.Equal(t, "number", items.Properties["score"].Type)
+	assert.Equal(t, "string", items.Properties["note"].Type)
+	assert.True(t, items.Properties["note"].Nullable)
+
+	shape, err = Testing.JSONShape([]byte(`[1,"a"]`))
+	require.NoError(t, err)
+	assert.Equal(t, "mixed", shape.Items.Type)
+}
+
+func TestFactories(t *testing.T) {
+	db := setupDB(t)
+	factory := Testing.NewFactory(t, db)
+
+	first, second := factory.User(), factory.User(func(u *Models.User) { u.Locale = "en" })
+	assert.NotEqual(t, first.Username, second.Username)
+	assert.True(t, first.ValidatePassword(Testing.DefaultPassword))
+	assert.Equal(t, "en", second.Locale)
+	assert.True(t, factory.Admin().IsAdmin())
+
+	alert := factory.Alert(nil, func(a *Models.Alert) { a.Severity = "critical" })
+	var rule Models.AlertRule
+	require.NoError(t, db.First(&rule, alert.RuleID).Error)
+	assert.Equal(t, rule.Name, alert.RuleName)
+	assert.Equal(t, "critical", alert.Severity)
+	assert.Greater(t, alert.Value, rule.Threshold)
+
+	metric := factory.Metric(func(m *Models.MonitoringMetric) { m.Name = "memory_usage" })
+	assert.NotZero(t, metric.ID)
+
+	samples := factory.MetricSamples("cpu_usage", 10, 20, 30)
+	require.Len(t, samples, 3)
+	assert.True(t, samples[0].Timestamp.Before(samples[2].Timestamp))
+	var count int64
+	db.Model(&Models.MetricSample{}).Where("name = ?", "cpu_usage").Count(&count)
+	assert.Equal(t, int64(3), count)
+	assert.False(t, strings.Contains(first.Password, Testing.DefaultPassword))
+}
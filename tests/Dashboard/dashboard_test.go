@@ -0,0 +1,83 @@
+package Dashboard
+
+import (
+	"cloud-platform-api/app/Dashboard"
+	"cloud-platform-api/app/Services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// apiHandler 记录转发的接口请求
+func apiHandler(paths *[]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*paths = append(*paths, r.Method+" "+r.URL.Path+" "+r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"success":true}`))
+	})
+}
+
+func serve(server http.Handler, method, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	return w
+}
+
+func TestServesEmbeddedAssets(t *testing.T) {
+	var paths []string
+	server := Dashboard.NewServer(apiHandler(&paths))
+
+	w := serve(server, http.MethodGet, "/")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), `<script src="/app.js"></script>`)
+	assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+	assert.Contains(t, w.Header().Get("Content-Security-Policy"), "default-src 'self'")
+
+	w = serve(server, http.MethodGet, "/app.js")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "javascript")
+	assert.Contains(t, w.Body.String(), "/api/v1/monitoring/metrics")
+
+	w = serve(server, http.MethodGet, "/app.css")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/css")
+
+	// 前端路由返回页面，不存在的静态文件返回404
+	w = serve(server, http.MethodGet, "/alerts/history")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "<title>管理仪表板</title>")
+	assert.Equal(t, http.StatusNotFound, serve(server, http.MethodGet, "/missing.js").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(server, http.MethodPost, "/").Code)
+	assert.Empty(t, paths)
+}
+
+func TestForwardsAPIRequests(t *testing.T) {
+	var paths []string
+	server := Dashboard.NewServer(apiHandler(&paths))
+
+	assert.Equal(t, http.StatusOK, serve(server, http.MethodPost, "/api/v1/auth/login").Code)
+	assert.Equal(t, http.StatusOK, serve(server, http.MethodGet, "/api/v1/monitoring/alerts").Code)
+	assert.Equal(t, http.StatusOK, serve(server, http.MethodGet, "/health/detailed").Code)
+	assert.Equal(t, []string{
+		"POST /api/v1/auth/login Bearer token",
+		"GET /api/v1/monitoring/alerts Bearer token",
+		"GET /health/detailed Bearer token",
+	}, paths)
+}
+
+func TestAdminRoutesStayOnAdminListener(t *testing.T) {
+	var paths []string
+	// 启用管理端口时API端口的处理器不提供管理路由，仪表板端口使用同一处理器
+	api := Services.RouteFilter(apiHandler(&paths), []string{"/api/v1/admin"}, false)
+	server := Dashboard.NewServer(api)
+
+	assert.Equal(t, http.StatusNotFound, serve(server, http.MethodGet, "/api/v1/admin/users").Code)
+	assert.Equal(t, http.StatusOK, serve(server, http.MethodGet, "/api/v1/monitoring/alerts").Code)
+	assert.Equal(t, []string{"GET /api/v1/monitoring/alerts Bearer token"}, paths)
+}
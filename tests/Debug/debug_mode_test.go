@@ -0,0 +1,226 @@
+package Debug
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Http/Routes"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"cloud-platform-api/app/Testing"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func debugConfig() *Config.DebugModeConfig {
+	return &Config.DebugModeConfig{Enabled: true, Header: "X-Debug", TTL: time.Minute, MaxRequests: 10, MaxEntries: 50}
+}
+
+// setupDB 使用日志管理器的GORM日志适配器打开测试数据库，语句经过 Trace 写入调试记录器
+func setupDB(t *testing.T) *gorm.DB {
+	logConfig := &Config.LogConfig{}
+	logConfig.SetDefaults()
+	logConfig.BasePath = t.TempDir()
+	logConfig.SQLLog.IncludeParams = true
+	manager := Services.NewLogManagerService(logConfig)
+	t.Cleanup(func() { manager.Close() })
+
+	gormLogger := manager.GormLogger().(*Services.GormLogger)
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "debug.db")), &gorm.Config{Logger: gormLogger})
+	require.NoError(t, err)
+	require.NoError(t, gormLogger.Install(db))
+	require.NoError(t, db.AutoMigrate(&Models.User{}))
+	return db
+}
+
+func TestDebugModeConfigValidate(t *testing.T) {
+	assert.NoError(t, (&Config.DebugModeConfig{}).Validate(), "未启用时不检查")
+	assert.NoError(t, debugConfig().Validate())
+
+	for _, modify := range []func(*Config.DebugModeConfig){
+		func(c *Config.DebugModeConfig) { c.Header = "" },
+		func(c *Config.DebugModeConfig) { c.TTL = 0 },
+		func(c *Config.DebugModeConfig) { c.MaxRequests = 0 },
+		func(c *Config.DebugModeConfig) { c.MaxEntries = 0 },
+	} {
+		config := debugConfig()
+		modify(config)
+		assert.Error(t, config.Validate())
+	}
+}
+
+func TestDebugRecorderLimitsAndSummary(t *testing.T) {
+	recorder := Services.NewDebugRecorder("req-1", http.MethodGet, "/x", 3)
+	begin := time.Now()
+	recorder.RecordQuery(Services.DebugQuery{SQL: "SELECT 1", DurationMs: 2}, begin)
+	recorder.RecordQuery(Services.DebugQuery{SQL: "SELECT 2", DurationMs: 3, Error: "boom"}, begin)
+	hit, miss := true, false
+	recorder.RecordCache(Services.DebugCacheOperation{Store: Services.DebugCacheStoreRedis, Operation: "get", Hit: &hit}, begin)
+	recorder.RecordCache(Services.DebugCacheOperation{Store: Services.DebugCacheStoreRedis, Operation: "get", Hit: &miss}, begin)
+	recorder.RecordHTTP(Services.DebugHTTPCall{Dependency: "github"}, begin)
+
+	request := recorder.Finish(http.StatusOK, "/x", "7")
+	assert.Equal(t, 2, request.Queries)
+	assert.Equal(t, 5.0, request.QueryMs)
+	assert.Equal(t, 1, request.QueryErrors)
+	assert.Equal(t, 1, request.CacheOps)
+	assert.Equal(t, 1, request.CacheHits)
+	assert.Equal(t, 0, request.HTTPCalls)
+	assert.Equal(t, 2, request.Dropped, "超过上限的操作只计数")
+	assert.Equal(t, "7", request.UserID)
+
+	// 记录器为 nil 时记录方法不做任何事
+	var none *Services.DebugRecorder
+	none.RecordQuery(Services.DebugQuery{}, begin)
+	assert.Nil(t, Services.DebugRecorderFromContext(context.Background()))
+}
+
+func TestDebugRequestStoreRetention(t *testing.T) {
+	config := debugConfig()
+	config.MaxRequests = 2
+	store := Services.NewDebugRequestStore(config)
+	now := time.Now()
+	store.SetClock(func() time.Time { return now })
+
+	for _, id := range []string{"a", "b", "c"} {
+		store.Save(Services.NewDebugRecorder(id, http.MethodGet, "/"+id, 10).Finish(http.StatusOK, "", ""))
+		now = now.Add(10 * time.Second)
+	}
+	_, ok := store.Get("a")
+	assert.False(t, ok, "超过数量上限时丢弃最早的记录")
+	summaries := store.List()
+	require.Len(t, summaries, 2)
+	assert.Equal(t, "c", summaries[0].RequestID)
+
+	now = now.Add(45 * time.Second)
+	_, ok = store.Get("b")
+	assert.False(t, ok, "超过保留时间的记录被清理")
+	_, ok = store.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, 1, store.Clear())
+}
+
+func TestDebugModeRecordsAdminRequests(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-jwt-secret-key-for-testing-only-32-chars")
+	Config.LoadConfig()
+	OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "测试", Version: "1.0.0"}))
+	t.Cleanup(func() {
+		OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "Cloud Platform API", Version: "1.0.0"}))
+	})
+
+	db := setupDB(t)
+	factory := Testing.NewFactory(t, db)
+	admin := factory.Admin()
+	adminToken := factory.Token(admin)
+	userToken := factory.Token(factory.User())
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer upstream.Close()
+	outbound := Services.NewOutboundHTTPClient(nil)
+	redisClient := Services.NewRedisUniversalClient(&Config.RedisConfig{Host: "127.0.0.1", Port: 1})
+	defer redisClient.Close()
+
+	store := Services.NewDebugRequestStore(debugConfig())
+	storageManager := Storage.NewStorageManager(&Config.StorageConfig{BasePath: t.TempDir()})
+	engine := gin.New()
+	engine.Use(Middleware.NewRequestIDMiddleware().Handle())
+	engine.Use(Middleware.NewDebugModeMiddleware(store, debugConfig()).Handle())
+	api := engine.Group("/api/v1/things")
+	api.Use(Middleware.NewAuthMiddleware().Handle())
+	api.GET("", func(c *gin.Context) {
+		ctx := c.Request.Context()
+		var users []Models.User
+		db.WithContext(ctx).Where("id = ?", admin.ID).Find(&users)
+		redisClient.Get(ctx, "things:1")
+		if resp, err := outbound.Get(ctx, "upstream", upstream.URL+"/ping?token=secret", nil); err == nil {
+			resp.Body.Close()
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "count": len(users)})
+	})
+	Routes.RegisterDebugRoutes(engine, storageManager, Controllers.NewDebugController(store))
+
+	request := func(token, path string, debug bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if debug {
+			req.Header.Set("X-Debug", "1")
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	// 不携带调试请求头时不记录
+	w := request(adminToken, "/api/v1/things", false)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Empty(t, w.Header().Get(Middleware.DebugIDHeader))
+	assert.Empty(t, store.List())
+
+	// 非管理员携带调试请求头时不记录
+	w = request(userToken, "/api/v1/things", true)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(Middleware.DebugIDHeader))
+	assert.Empty(t, store.List())
+
+	w = request(adminToken, "/api/v1/things", true)
+	require.Equal(t, http.StatusOK, w.Code)
+	debugID := w.Header().Get(Middleware.DebugIDHeader)
+	require.NotEmpty(t, debugID)
+	assert.Equal(t, w.Header().Get("X-Request-ID"), debugID)
+
+	w = request(adminToken, Services.DebugRequestAdminPath+"/"+debugID, false)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		Data Services.DebugRequest `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	record := response.Data
+	assert.Equal(t, "/api/v1/things", record.Route)
+	assert.Equal(t, http.StatusOK, record.StatusCode)
+	assert.Equal(t, record.Queries, len(record.SQL))
+
+	// 认证中间件按ID查询用户不一定带请求上下文，处理器中的查询一定被记录
+	found := false
+	for _, query := range record.SQL {
+		if query.Table == "users" && query.Operation == "select" && query.Rows == 1 {
+			found = true
+		}
+	}
+	assert.True(t, found, "%+v", record.SQL)
+
+	require.Len(t, record.Cache, 1)
+	assert.Equal(t, Services.DebugCacheStoreRedis, record.Cache[0].Store)
+	assert.Equal(t, "get", record.Cache[0].Operation)
+	assert.Equal(t, "things:1", record.Cache[0].Key)
+	assert.NotEmpty(t, record.Cache[0].Error, "Redis不可用时记录错误")
+
+	require.Len(t, record.HTTP, 1)
+	assert.Equal(t, "upstream", record.HTTP[0].Dependency)
+	assert.Equal(t, http.StatusAccepted, record.HTTP[0].StatusCode)
+	assert.Equal(t, upstream.URL+"/ping", record.HTTP[0].URL, "URL不包含查询参数")
+	assert.Equal(t, 1, record.HTTP[0].Attempt)
+
+	assert.Equal(t, http.StatusNotFound, request(adminToken, Services.DebugRequestAdminPath+"/missing", false).Code)
+	assert.Equal(t, http.StatusForbidden, request(userToken, Services.DebugRequestAdminPath, false).Code)
+	w = request(adminToken, Services.DebugRequestAdminPath, false)
+	assert.Contains(t, w.Body.String(), debugID)
+}
@@ -0,0 +1,385 @@
+package EventBus
+
+import (
+	"bufio"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Requests"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupEventDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "events.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.User{}, &Models.OutboxEvent{}))
+	return db
+}
+
+func newEventBus(db *gorm.DB) *Services.EventBus {
+	return Services.NewEventBus(db, Config.EventBusConfig{
+		Enabled:      true,
+		PollInterval: time.Second,
+		BatchSize:    10,
+		MaxAttempts:  3,
+		RetryBackoff: time.Minute,
+	})
+}
+
+// makeDue 使事件立即到期，跳过退避等待
+func makeDue(t *testing.T, db *gorm.DB, id uint) {
+	require.NoError(t, db.Model(&Models.OutboxEvent{}).Where("id = ?", id).
+		Update("next_attempt_at", time.Now().Add(-time.Second)).Error)
+}
+
+func loadEvent(t *testing.T, db *gorm.DB, id uint) Models.OutboxEvent {
+	var event Models.OutboxEvent
+	require.NoError(t, db.First(&event, id).Error)
+	return event
+}
+
+func TestPublishIsPartOfTransaction(t *testing.T) {
+	db := setupEventDB(t)
+	bus := newEventBus(db)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		require.NoError(t, tx.Create(&Models.User{Username: "rollback", Email: "rb@example.com", Password: "x"}).Error)
+		_, err := bus.Publish(tx, Services.EventUserRegistered, "user", "1", map[string]string{"username": "rollback"})
+		require.NoError(t, err)
+		return errors.New("业务失败")
+	})
+	require.Error(t, err)
+
+	var count int64
+	db.Model(&Models.OutboxEvent{}).Count(&count)
+	assert.Equal(t, int64(0), count, "事务回滚时事件也应回滚")
+
+	event, err := bus.Publish(nil, Services.EventUserRegistered, "user", "2", map[string]string{"username": "ok"})
+	require.NoError(t, err)
+	assert.Len(t, event.EventID, 36)
+	assert.Equal(t, Models.OutboxPending, event.Status)
+}
+
+func TestDispatchRetriesOnlyFailedTargets(t *testing.T) {
+	db := setupEventDB(t)
+	bus := newEventBus(db)
+
+	var mu sync.Mutex
+	calls := map[string]int{}
+	failAudit := true
+	bus.Subscribe(Services.EventUserRegistered, "welcome", func(ctx context.Context, event Services.DomainEvent) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls["welcome"]++
+		var payload map[string]string
+		require.NoError(t, event.DecodePayload(&payload))
+		assert.Equal(t, "alice", payload["username"])
+		return nil
+	})
+	bus.Subscribe(Services.EventAllTypes, "audit", func(ctx context.Context, event Services.DomainEvent) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls["audit"]++
+		if failAudit {
+			return errors.New("审计服务不可用")
+		}
+		return nil
+	})
+	bus.Subscribe(Services.EventBackupCompleted, "other", func(ctx context.Context, event Services.DomainEvent) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls["other"]++
+		return nil
+	})
+
+	record, err := bus.Publish(nil, Services.EventUserRegistered, "user", "7", map[string]string{"username": "alice"})
+	require.NoError(t, err)
+
+	dispatched, err := bus.DispatchPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, dispatched)
+
+	event := loadEvent(t, db, record.ID)
+	assert.Equal(t, Models.OutboxPending, event.Status)
+	assert.Equal(t, 1, event.Attempts)
+	assert.True(t, event.DeliveredTo("handler:welcome"))
+	assert.Contains(t, event.LastError, "handler:audit")
+	assert.WithinDuration(t, time.Now().Add(time.Minute), event.NextAttemptAt, 5*time.Second)
+
+	// 未到重试时间不会再次投递
+	dispatched, err = bus.DispatchPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, dispatched)
+	assert.Equal(t, 1, calls["audit"])
+
+	failAudit = false
+	makeDue(t, db, record.ID)
+	dispatched, err = bus.DispatchPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, dispatched)
+
+	event = loadEvent(t, db, record.ID)
+	assert.Equal(t, Models.OutboxDispatched, event.Status)
+	assert.NotNil(t, event.DispatchedAt)
+	assert.Empty(t, event.LastError)
+	assert.Equal(t, map[string]int{"welcome": 1, "audit": 2}, calls)
+}
+
+func TestDispatchMarksFailedAndRetry(t *testing.T) {
+	db := setupEventDB(t)
+	bus := newEventBus(db)
+
+	failing := true
+	bus.Subscribe(Services.EventAlertFired, "pager", func(ctx context.Context, event Services.DomainEvent) error {
+		if failing {
+			panic("pager down")
+		}
+		return nil
+	})
+
+	record, err := bus.Publish(nil, Services.EventAlertFired, "alert", "1", nil)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		makeDue(t, db, record.ID)
+		_, err := bus.DispatchPending(context.Background())
+		require.NoError(t, err)
+	}
+
+	event := loadEvent(t, db, record.ID)
+	assert.Equal(t, Models.OutboxFailed, event.Status)
+	assert.Equal(t, 3, event.Attempts)
+	assert.Contains(t, event.LastError, "pager down")
+
+	stats, err := bus.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats[Models.OutboxFailed])
+
+	assert.ErrorIs(t, bus.Retry(9999), Services.ErrOutboxEventNotFound)
+	failing = false
+	require.NoError(t, bus.Retry(record.ID))
+	assert.Error(t, bus.Retry(record.ID), "只能重试失败的事件")
+
+	dispatched, err := bus.DispatchPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, dispatched)
+	assert.Equal(t, Models.OutboxDispatched, loadEvent(t, db, record.ID).Status)
+}
+
+func TestDispatchClaimsEventOnce(t *testing.T) {
+	db := setupEventDB(t)
+	first, second := newEventBus(db), newEventBus(db)
+
+	var mu sync.Mutex
+	count := 0
+	handler := func(ctx context.Context, event Services.DomainEvent) error {
+		mu.Lock()
+		defer mu.Unlock()
+		count++
+		return nil
+	}
+	first.Subscribe(Services.EventAllTypes, "counter", handler)
+	second.Subscribe(Services.EventAllTypes, "counter", handler)
+
+	for i := 0; i < 5; i++ {
+		_, err := first.Publish(nil, Services.EventBackupCompleted, "backup", fmt.Sprint(i), nil)
+		require.NoError(t, err)
+	}
+
+	var wg sync.WaitGroup
+	for _, bus := range []*Services.EventBus{first, second} {
+		wg.Add(1)
+		go func(bus *Services.EventBus) {
+			defer wg.Done()
+			bus.DispatchPending(context.Background())
+		}(bus)
+	}
+	wg.Wait()
+	first.DispatchPending(context.Background())
+
+	assert.Equal(t, 5, count)
+}
+
+func TestRegisterPublishesUserRegistered(t *testing.T) {
+	db := setupEventDB(t)
+	bus := newEventBus(db)
+	Services.SetDefaultEventBus(bus)
+	defer Services.SetDefaultEventBus(nil)
+
+	user, err := Services.NewAuthServiceWithDB(db).Register(Requests.RegisterRequest{
+		Username: "newuser",
+		Email:    "newuser@example.com",
+		Password: "Str0ng!Passw0rd",
+	})
+	require.NoError(t, err)
+
+	var event Models.OutboxEvent
+	require.NoError(t, db.Where("event_type = ?", Services.EventUserRegistered).First(&event).Error)
+	assert.Equal(t, "user", event.AggregateType)
+	assert.Equal(t, fmt.Sprint(user.ID), event.AggregateID)
+	assert.Contains(t, event.Payload, `"username":"newuser"`)
+	assert.NotContains(t, event.Payload, "password")
+}
+
+func TestKafkaEventBroker(t *testing.T) {
+	var body map[string][]map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/topics/domain-events", r.URL.Path)
+		assert.Equal(t, "application/vnd.kafka.json.v2+json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body["records"][0]["key"] == "bad" {
+			io.WriteString(w, `{"offsets":[{"partition":null,"offset":null,"error_code":40403,"error":"topic not found"}]}`)
+			return
+		}
+		io.WriteString(w, `{"offsets":[{"partition":0,"offset":12}]}`)
+	}))
+	defer server.Close()
+
+	broker := Services.NewKafkaEventBroker(&Config.EventBusKafkaConfig{RestURL: server.URL, Topic: "domain-events"})
+	event := Services.DomainEvent{ID: "e1", Type: Services.EventUserRegistered, AggregateID: "42", Payload: json.RawMessage(`{}`)}
+	require.NoError(t, broker.Publish(context.Background(), event))
+	assert.Equal(t, "42", body["records"][0]["key"])
+	assert.Equal(t, "e1", body["records"][0]["value"].(map[string]interface{})["id"])
+
+	event.AggregateID = "bad"
+	assert.ErrorContains(t, broker.Publish(context.Background(), event), "40403")
+}
+
+func TestRabbitMQEventBroker(t *testing.T) {
+	routed := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/exchanges/%2F/domain_events/publish", r.URL.EscapedPath())
+		user, pass, _ := r.BasicAuth()
+		assert.Equal(t, "guest:guest", user+":"+pass)
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, Services.EventBackupFailed, body["routing_key"])
+		fmt.Fprintf(w, `{"routed":%t}`, routed)
+	}))
+	defer server.Close()
+
+	broker := Services.NewRabbitMQEventBroker(&Config.EventBusRabbitMQConfig{
+		ManagementURL: server.URL, VHost: "/", Exchange: "domain_events", Username: "guest", Password: "guest",
+	})
+	event := Services.DomainEvent{ID: "e2", Type: Services.EventBackupFailed, Payload: json.RawMessage(`{}`)}
+	require.NoError(t, broker.Publish(context.Background(), event))
+
+	routed = false
+	assert.ErrorContains(t, broker.Publish(context.Background(), event), "未路由")
+}
+
+// fakeNATSServer 简化的 NATS 服务器，记录收到的 PUB 主题，主题包含 deny 时返回 -ERR
+func fakeNATSServer(t *testing.T, token string) (string, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	subjects := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				io.WriteString(conn, "INFO {\"server_id\":\"test\",\"auth_required\":true}\r\n")
+				reader := bufio.NewReader(conn)
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					line = strings.TrimSpace(line)
+					switch {
+					case strings.HasPrefix(line, "CONNECT "):
+						var options map[string]interface{}
+						json.Unmarshal([]byte(strings.TrimPrefix(line, "CONNECT ")), &options)
+						if options["auth_token"] != token {
+							io.WriteString(conn, "-ERR 'Authorization Violation'\r\n")
+							return
+						}
+					case line == "PING":
+						io.WriteString(conn, "PONG\r\n")
+					case strings.HasPrefix(line, "PUB "):
+						var subject string
+						var size int
+						fmt.Sscanf(line, "PUB %s %d", &subject, &size)
+						payload := make([]byte, size+2)
+						if _, err := io.ReadFull(reader, payload); err != nil {
+							return
+						}
+						if strings.Contains(subject, "deny") {
+							io.WriteString(conn, "-ERR 'Permissions Violation for Publish'\r\n")
+							continue
+						}
+						subjects <- subject
+					}
+				}
+			}(conn)
+		}
+	}()
+	return listener.Addr().String(), subjects
+}
+
+func TestNATSEventBroker(t *testing.T) {
+	address, subjects := fakeNATSServer(t, "secret")
+
+	broker := Services.NewNATSEventBroker(&Config.EventBusNATSConfig{Address: address, SubjectPrefix: "events.", Token: "secret"})
+	defer broker.Close()
+	event := Services.DomainEvent{ID: "e3", Type: Services.EventAlertResolved, Payload: json.RawMessage(`{}`)}
+	require.NoError(t, broker.Publish(context.Background(), event))
+	require.NoError(t, broker.Publish(context.Background(), event))
+	assert.Equal(t, "events.alert.resolved", <-subjects)
+	assert.Equal(t, "events.alert.resolved", <-subjects)
+
+	event.Type = "deny"
+	assert.ErrorContains(t, broker.Publish(context.Background(), event), "Permissions Violation")
+
+	// 出错后重新连接
+	event.Type = Services.EventAlertFired
+	require.NoError(t, broker.Publish(context.Background(), event))
+	assert.Equal(t, "events.alert.fired", <-subjects)
+
+	denied := Services.NewNATSEventBroker(&Config.EventBusNATSConfig{Address: address, Token: "wrong"})
+	assert.ErrorContains(t, denied.Publish(context.Background(), event), "Authorization Violation")
+}
+
+func TestEventBusConfigValidate(t *testing.T) {
+	config := Config.EventBusConfig{}
+	assert.NoError(t, config.Validate(), "未启用时不检查")
+
+	config = Config.EventBusConfig{Enabled: true, PollInterval: time.Second, BatchSize: 10, MaxAttempts: 3, RetryBackoff: time.Second}
+	assert.NoError(t, config.Validate())
+
+	config.Kafka = Config.EventBusKafkaConfig{Enabled: true, RestURL: "not a url", Topic: "t"}
+	assert.Error(t, config.Validate())
+	config.Kafka.RestURL = "http://kafka-rest:8082"
+	assert.NoError(t, config.Validate())
+
+	config.RabbitMQ = Config.EventBusRabbitMQConfig{Enabled: true, ManagementURL: "http://rabbitmq:15672"}
+	assert.Error(t, config.Validate())
+
+	config.RabbitMQ.Exchange = "domain_events"
+	config.BatchSize = 0
+	assert.Error(t, config.Validate())
+}
@@ -0,0 +1,294 @@
+package Exports
+
+import (
+	"archive/zip"
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func setupExportService(t *testing.T, config *Config.ExportConfig) (*Services.DataExportService, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "exports.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.SecurityEvent{}, &Models.LoginAttempt{}, &Models.ExportSchedule{},
+		&Models.Comment{}, &Models.User{}, &Models.MailMessage{}, &Models.MailSuppression{}))
+
+	if config == nil {
+		config = &Config.ExportConfig{}
+		config.SetDefaults()
+	}
+	emailConfig := &Config.EmailConfig{}
+	emailConfig.SetDefaults()
+	emailConfig.From = "exports@example.com"
+
+	service := Services.NewDataExportService(db, config)
+	service.SetMonitoringCore(Services.NewMonitoringCore())
+	service.SetMailService(Services.NewMailService(db, emailConfig))
+	return service, db
+}
+
+func seedSecurityEvents(t *testing.T, db *gorm.DB) time.Time {
+	base := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	events := []Models.SecurityEvent{
+		{EventType: "sql_injection", EventLevel: "high", IPAddress: "10.0.0.1", Details: "=HYPERLINK(\"http://evil\")", RiskScore: 90, CreatedAt: base},
+		{EventType: "brute_force", EventLevel: "medium", IPAddress: "10.0.0.2", Details: "多次登录失败", RiskScore: 60, CreatedAt: base.Add(time.Hour)},
+		{EventType: "sql_injection", EventLevel: "high", IPAddress: "10.0.0.3", Details: "union select", RiskScore: 85, CreatedAt: base.Add(47 * time.Hour)},
+	}
+	require.NoError(t, db.Create(&events).Error)
+	return base
+}
+
+func readCSV(t *testing.T, data []byte) [][]string {
+	require.True(t, bytes.HasPrefix(data, []byte("\uFEFF")), "CSV以UTF-8 BOM开头")
+	records, err := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\uFEFF")))).ReadAll()
+	require.NoError(t, err)
+	return records
+}
+
+func exportQuery(t *testing.T, dataset, raw string) Services.DataExportRequest {
+	values, err := url.ParseQuery(raw)
+	require.NoError(t, err)
+	q, err := Services.ParseExportQuery(dataset, values)
+	require.NoError(t, err)
+	return Services.DataExportRequest{Dataset: dataset, Format: Services.ExportFormatCSV, Query: q}
+}
+
+func TestExportSecurityEventsCSV(t *testing.T) {
+	service, db := setupExportService(t, nil)
+	base := seedSecurityEvents(t, db)
+
+	// 筛选、排序和字段与列表接口相同，以公式字符开头的文本加单引号前缀
+	var buf bytes.Buffer
+	req := exportQuery(t, Services.ExportDatasetSecurityEvents, "filter[event_type]=sql_injection&sort=created_at&fields=id,ip_address,details,risk_score")
+	result, err := service.Export(context.Background(), &buf, req)
+	require.NoError(t, err)
+	assert.Equal(t, Services.DataExportResult{Rows: 2}, result)
+	records := readCSV(t, buf.Bytes())
+	require.Len(t, records, 3)
+	assert.Equal(t, []string{"id", "ip_address", "details", "risk_score"}, records[0])
+	assert.Equal(t, []string{"1", "10.0.0.1", `'=HYPERLINK("http://evil")`, "90"}, records[1])
+	assert.Equal(t, "10.0.0.3", records[2][1])
+
+	// 默认按创建时间倒序导出全部字段，超过最大行数时截断
+	buf.Reset()
+	req = exportQuery(t, Services.ExportDatasetSecurityEvents, "")
+	req.MaxRows = 2
+	result, err = service.Export(context.Background(), &buf, req)
+	require.NoError(t, err)
+	assert.Equal(t, Services.DataExportResult{Rows: 2, Truncated: true}, result)
+	records = readCSV(t, buf.Bytes())
+	require.Len(t, records, 3)
+	assert.Equal(t, "id", records[0][0])
+	assert.Equal(t, "created_at", records[0][1])
+	assert.Equal(t, []string{"3", "2"}, []string{records[1][0], records[2][0]})
+
+	buf.Reset()
+	req = exportQuery(t, Services.ExportDatasetSecurityEvents, "")
+	req.Since = base.Add(30 * time.Minute)
+	result, err = service.Export(context.Background(), &buf, req)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Rows)
+
+	_, err = Services.ParseExportQuery(Services.ExportDatasetSecurityEvents, url.Values{"sort": {"details"}})
+	assert.Error(t, err)
+	_, err = Services.ParseExportQuery("users", url.Values{})
+	assert.ErrorIs(t, err, Services.ErrUnknownExportDataset)
+}
+
+func TestExportLoginAttemptsXLSX(t *testing.T) {
+	service, db := setupExportService(t, nil)
+	now := time.Now().Truncate(time.Second)
+	attempts := []Models.LoginAttempt{
+		{Username: "alice", IPAddress: "10.0.0.1", Success: false, FailureReason: "密码错误", AttemptTime: now.Add(-time.Hour)},
+		{Username: "alice", IPAddress: "10.0.0.1", Success: true, AttemptTime: now},
+		{Username: "bob<&>", IPAddress: "10.0.0.2", Success: false, FailureReason: "账户锁定", AttemptTime: now.Add(-2 * time.Hour)},
+	}
+	require.NoError(t, db.Create(&attempts).Error)
+
+	var buf bytes.Buffer
+	req := exportQuery(t, Services.ExportDatasetLoginAttempts, "filter[success]=false&fields=username,success,failure_reason")
+	req.Format = Services.ExportFormatXLSX
+	result, err := service.Export(context.Background(), &buf, req)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Rows)
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	parts := make(map[string]string)
+	for _, file := range archive.File {
+		rc, err := file.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		parts[file.Name] = string(data)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		assert.Contains(t, parts, name)
+	}
+	assert.Contains(t, parts["xl/workbook.xml"], `<sheet name="login_attempts"`)
+
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet, `state="frozen"`)
+	assert.Contains(t, sheet, `<c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">username</t></is></c>`)
+	// 默认按尝试时间倒序，布尔值为布尔单元格，文本转义
+	assert.Contains(t, sheet, `<c r="B2" t="b"><v>0</v></c>`)
+	assert.Less(t, strings.Index(sheet, "密码错误"), strings.Index(sheet, "账户锁定"))
+	assert.Contains(t, sheet, "bob&lt;&amp;&gt;")
+	assert.NotContains(t, sheet, `<row r="4">`)
+}
+
+func TestExportAlertsWithComments(t *testing.T) {
+	service, db := setupExportService(t, nil)
+	core := Services.NewMonitoringCore()
+	depth := 20.0
+	require.NoError(t, core.RegisterCollector(Services.NewMetricCollector("queue", func(ctx context.Context) (map[string]float64, error) {
+		return map[string]float64{"queue_depth": depth}, nil
+	})))
+	require.NoError(t, core.AddRule(&Services.AlertRule{
+		ID: "queue_backlog", Name: "队列积压", Metric: "queue_depth", Condition: ">", Threshold: 10,
+		Level: Services.AlertLevelWarning, Enabled: true,
+	}))
+	require.NoError(t, core.Evaluate())
+	alerts := core.Alerts("active", 0)
+	require.Len(t, alerts, 1)
+
+	user := Models.User{Username: "oncall", Email: "oncall@example.com", Password: "x", Status: 1}
+	require.NoError(t, db.Create(&user).Error)
+	comments := Services.NewCommentService(db)
+	comments.SetMonitoringCore(core)
+	_, err := comments.Create(Services.CommentActor{UserID: user.ID}, Models.CommentResourceAlert, alerts[0].ID, nil, "已扩容消费者")
+	require.NoError(t, err)
+	service.SetMonitoringCore(core)
+	service.SetCommentService(comments)
+
+	var buf bytes.Buffer
+	result, err := service.Export(context.Background(), &buf, exportQuery(t, Services.ExportDatasetAlerts, "fields=rule_id,level,value"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Rows)
+	records := readCSV(t, buf.Bytes())
+	require.Len(t, records, 2)
+	assert.Equal(t, []string{"rule_id", "level", "value", "comments"}, records[0])
+	assert.Equal(t, []string{"queue_backlog", "warning", "20"}, records[1][:3])
+	assert.Contains(t, records[1][3], "oncall: 已扩容消费者")
+
+	buf.Reset()
+	req := exportQuery(t, Services.ExportDatasetAlerts, "")
+	req.Severity = "critical"
+	result, err = service.Export(context.Background(), &buf, req)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Rows)
+}
+
+func TestExportEndpoint(t *testing.T) {
+	service, db := setupExportService(t, nil)
+	seedSecurityEvents(t, db)
+	router := gin.New()
+	controller := Controllers.NewExportController(service)
+	router.GET("/api/v1/security/events/export", controller.ExportSecurityEvents)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/security/events/export?format=csv&filter[event_level]=high", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Regexp(t, `^attachment; filename=security_events-\d{8}-\d{6}\.csv$`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "2", w.Header().Get("X-Export-Rows"))
+	assert.Equal(t, "false", w.Header().Get("X-Export-Truncated"))
+	assert.Len(t, readCSV(t, w.Body.Bytes()), 3)
+
+	for _, path := range []string{
+		"/api/v1/security/events/export?format=pdf",
+		"/api/v1/security/events/export?filter[unknown]=1",
+		"/api/v1/security/events/export?since=yesterday",
+	} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+	}
+}
+
+func TestExportScheduleDelivery(t *testing.T) {
+	config := &Config.ExportConfig{}
+	config.SetDefaults()
+	config.MaxAttachmentRows = 1
+	service, db := setupExportService(t, config)
+	seedSecurityEvents(t, db)
+
+	_, err := service.CreateSchedule(Services.ExportScheduleInput{
+		Name: "高危事件", Dataset: Services.ExportDatasetSecurityEvents, Schedule: "0 8 * * *", Recipients: []string{"not-an-email"},
+	}, 1)
+	assert.ErrorIs(t, err, Services.ErrInvalidExportSchedule)
+	_, err = service.CreateSchedule(Services.ExportScheduleInput{
+		Name: "高危事件", Dataset: Services.ExportDatasetSecurityEvents, Query: "sort=details", Schedule: "0 8 * * *", Recipients: []string{"ops@example.com"},
+	}, 1)
+	assert.ErrorIs(t, err, Services.ErrInvalidExportSchedule)
+
+	schedule, err := service.CreateSchedule(Services.ExportScheduleInput{
+		Name:       "高危事件",
+		Dataset:    Services.ExportDatasetSecurityEvents,
+		Format:     Services.ExportFormatXLSX,
+		Query:      "?filter[event_level]=high",
+		Since:      "72h",
+		Schedule:   "0 8 * * *",
+		Recipients: []string{"ops@example.com", "SecOps <secops@example.com>"},
+	}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "filter[event_level]=high", schedule.Query)
+	assert.Equal(t, "ops@example.com,secops@example.com", schedule.Recipients)
+	require.NotNil(t, schedule.NextRunAt)
+	dueAt := *schedule.NextRunAt
+
+	ctx := context.Background()
+	assert.Equal(t, 0, service.RunDueSchedules(ctx, dueAt.Add(-time.Minute)))
+	assert.Equal(t, 1, service.RunDueSchedules(ctx, dueAt.Add(time.Minute)))
+	assert.Equal(t, 0, service.RunDueSchedules(ctx, dueAt.Add(2*time.Minute)))
+
+	stored, err := service.GetSchedule(schedule.ID)
+	require.NoError(t, err)
+	assert.Equal(t, Services.ExportRunSuccess, stored.LastStatus, stored.LastError)
+	assert.Equal(t, 1, stored.LastRows)
+	assert.Equal(t, 1, stored.RunCount)
+	assert.True(t, stored.NextRunAt.After(dueAt))
+
+	// 附件超过行数上限时截断，并在邮件正文中说明
+	var message Models.MailMessage
+	require.NoError(t, db.First(&message).Error)
+	assert.Equal(t, "ops@example.com,secops@example.com", message.Recipients)
+	var payload Services.Mail
+	require.NoError(t, json.Unmarshal([]byte(message.Payload), &payload))
+	assert.Contains(t, payload.Subject, "高危事件")
+	assert.Contains(t, payload.Text, "附件只包含前 1 行")
+	require.Len(t, payload.Attachments, 1)
+	assert.Regexp(t, `^security_events-\d{8}-\d{6}\.xlsx$`, payload.Attachments[0].Filename)
+	_, err = zip.NewReader(bytes.NewReader(payload.Attachments[0].Content), int64(len(payload.Attachments[0].Content)))
+	assert.NoError(t, err)
+
+	assert.NoError(t, service.DeleteSchedule(schedule.ID))
+	assert.ErrorIs(t, service.DeleteSchedule(schedule.ID), Services.ErrExportScheduleNotFound)
+}
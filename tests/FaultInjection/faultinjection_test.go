@@ -0,0 +1,254 @@
+package FaultInjection
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Http/Routes"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"cloud-platform-api/app/Testing"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func setupDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "fault.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.User{}))
+	return db
+}
+
+func TestInjectValidatesInput(t *testing.T) {
+	injector := Services.NewFaultInjector(&Config.FaultInjectionConfig{MaxDuration: time.Hour, MaxLatency: time.Second})
+
+	invalid := []Services.FaultInjectionInput{
+		{Type: "crash", Path: "/api/v1/users"},
+		{Type: Services.FaultError},
+		{Type: Services.FaultError, Path: "/api/v1/users", Service: Services.FaultServiceDatabase},
+		{Type: Services.FaultError, Path: "api/v1/users"},
+		{Type: Services.FaultError, Service: "kafka"},
+		{Type: Services.FaultLatency, Path: "/api/v1/users"},
+		{Type: Services.FaultLatency, Path: "/api/v1/users", LatencyMs: 5000},
+		{Type: Services.FaultError, Path: "/api/v1/users", StatusCode: 200},
+		{Type: Services.FaultError, Path: "/api/v1/users", Probability: 1.5},
+		{Type: Services.FaultError, Path: "/api/v1/users", DurationSeconds: 7200},
+		{Type: Services.FaultDBDisconnect, Service: Services.FaultServiceRedis},
+	}
+	for _, input := range invalid {
+		_, err := injector.Inject(input, 1)
+		assert.ErrorIs(t, err, Services.ErrInvalidFaultInjection, "%+v", input)
+	}
+
+	fault, err := injector.Inject(Services.FaultInjectionInput{Type: Services.FaultError, Method: "get", Path: "/api/v1/users"}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodGet, fault.Method)
+	assert.Equal(t, http.StatusServiceUnavailable, fault.StatusCode)
+	assert.Equal(t, 1.0, fault.Probability)
+	assert.NotEmpty(t, fault.Message)
+	assert.Equal(t, 5*time.Minute, fault.ExpiresAt.Sub(fault.CreatedAt))
+
+	fault, err = injector.Inject(Services.FaultInjectionInput{Type: Services.FaultRedisTimeout}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, Services.FaultServiceRedis, fault.Service)
+}
+
+func TestInjectionsExpire(t *testing.T) {
+	injector := Services.NewFaultInjector(nil)
+	now := time.Now()
+	injector.SetClock(func() time.Time { return now })
+
+	short, err := injector.Inject(Services.FaultInjectionInput{Type: Services.FaultError, Path: "/a", DurationSeconds: 60}, 1)
+	require.NoError(t, err)
+	_, err = injector.Inject(Services.FaultInjectionInput{Type: Services.FaultError, Path: "/a", DurationSeconds: 600}, 1)
+	require.NoError(t, err)
+	assert.Len(t, injector.RouteFaults(http.MethodGet, "/a", ""), 2)
+
+	now = now.Add(2 * time.Minute)
+	assert.Len(t, injector.RouteFaults(http.MethodGet, "/a", ""), 1)
+	faults := injector.List()
+	require.Len(t, faults, 1)
+	assert.Equal(t, int64(2), faults[0].Hits)
+	assert.ErrorIs(t, injector.Remove(short.ID), Services.ErrFaultInjectionNotFound)
+
+	now = now.Add(10 * time.Minute)
+	assert.Empty(t, injector.List())
+	assert.Equal(t, 0, injector.Clear())
+}
+
+func TestRouteFaultMiddleware(t *testing.T) {
+	injector := Services.NewFaultInjector(nil)
+	engine := gin.New()
+	engine.Use(Middleware.NewFaultInjectionMiddleware(injector).Handle())
+	ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"success": true}) }
+	engine.GET("/api/v1/users/:id", ok)
+	engine.POST("/api/v1/users/:id", ok)
+	engine.GET("/api/v1/posts", ok)
+	engine.DELETE(Services.FaultInjectionAdminPath, ok)
+
+	request := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	fault, err := injector.Inject(Services.FaultInjectionInput{
+		Type: Services.FaultError, Method: http.MethodGet, Path: "/api/v1/users/:id", StatusCode: http.StatusBadGateway, Message: "上游不可用",
+	}, 1)
+	require.NoError(t, err)
+	w := request(http.MethodGet, "/api/v1/users/7")
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Equal(t, fault.ID, w.Header().Get("X-Fault-Injection"))
+	assert.Contains(t, w.Body.String(), "上游不可用")
+	assert.Equal(t, http.StatusOK, request(http.MethodPost, "/api/v1/users/7").Code)
+
+	_, err = injector.Inject(Services.FaultInjectionInput{Type: Services.FaultLatency, Path: "/api/v1/p*", LatencyMs: 50}, 1)
+	require.NoError(t, err)
+	start := time.Now()
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/api/v1/posts").Code)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// 全路径通配也不影响管理接口
+	_, err = injector.Inject(Services.FaultInjectionInput{Type: Services.FaultError, Path: "/*"}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, request(http.MethodPost, "/api/v1/users/7").Code)
+	assert.Equal(t, http.StatusOK, request(http.MethodDelete, Services.FaultInjectionAdminPath).Code)
+}
+
+func TestDatabaseFaults(t *testing.T) {
+	db := setupDB(t)
+	injector := Services.NewFaultInjector(nil)
+	require.NoError(t, injector.InstallGormCallbacks(db))
+	require.NoError(t, db.Create(&Models.User{Username: "before", Email: "before@example.com", Password: "x"}).Error)
+
+	fault, err := injector.Inject(Services.FaultInjectionInput{Type: Services.FaultDBDisconnect}, 1)
+	require.NoError(t, err)
+	var users []Models.User
+	err = db.Find(&users).Error
+	assert.ErrorIs(t, err, driver.ErrBadConn)
+	assert.ErrorIs(t, err, Services.ErrFaultInjected)
+	err = db.Create(&Models.User{Username: "during", Email: "during@example.com", Password: "x"}).Error
+	assert.ErrorIs(t, err, driver.ErrBadConn)
+	var count int64
+	assert.Error(t, db.Raw("SELECT COUNT(*) FROM users").Scan(&count).Error)
+
+	require.NoError(t, injector.Remove(fault.ID))
+	require.NoError(t, db.Model(&Models.User{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	// 依赖延迟在查询前等待，请求取消时提前返回
+	_, err = injector.Inject(Services.FaultInjectionInput{Type: Services.FaultLatency, Service: Services.FaultServiceDatabase, LatencyMs: 1000}, 1)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = db.WithContext(ctx).Find(&users).Error
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRedisTimeoutFault(t *testing.T) {
+	injector := Services.NewFaultInjector(nil)
+	Services.SetDefaultFaultInjector(injector)
+	t.Cleanup(func() { Services.SetDefaultFaultInjector(nil) })
+
+	_, err := injector.Inject(Services.FaultInjectionInput{Type: Services.FaultRedisTimeout}, 1)
+	require.NoError(t, err)
+
+	// 钩子在建立连接之前返回注入的错误，不需要可用的Redis
+	client := Services.NewRedisUniversalClient(&Config.RedisConfig{Host: "127.0.0.1", Port: 1})
+	defer client.Close()
+	err = client.Get(context.Background(), "key").Err()
+	require.Error(t, err)
+	assert.ErrorIs(t, err, Services.ErrFaultInjected)
+	var netErr net.Error
+	require.True(t, errors.As(err, &netErr))
+	assert.True(t, netErr.Timeout())
+
+	_, err = client.Pipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		pipe.Get(context.Background(), "a")
+		return nil
+	})
+	assert.ErrorIs(t, err, Services.ErrFaultInjected)
+
+	injector.Clear()
+	err = client.Get(context.Background(), "key").Err()
+	assert.NotErrorIs(t, err, Services.ErrFaultInjected)
+}
+
+func TestFaultInjectionAdminAPI(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-jwt-secret-key-for-testing-only-32-chars")
+	Config.LoadConfig()
+	OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "测试", Version: "1.0.0"}))
+	t.Cleanup(func() {
+		OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "Cloud Platform API", Version: "1.0.0"}))
+	})
+
+	db := setupDB(t)
+	factory := Testing.NewFactory(t, db)
+	injector := Services.NewFaultInjector(nil)
+	engine := gin.New()
+	Routes.RegisterFaultInjectionRoutes(engine, Storage.NewStorageManager(&Config.StorageConfig{BasePath: t.TempDir()}),
+		Controllers.NewFaultInjectionController(injector))
+
+	adminToken := factory.Token(factory.Admin())
+	request := func(token, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(adminToken, http.MethodPost, Services.FaultInjectionAdminPath, `{"type":"db_disconnect","duration_seconds":30}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Data Services.FaultInjection `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, Services.FaultServiceDatabase, created.Data.Service)
+	assert.NotZero(t, created.Data.CreatedBy)
+
+	assert.Equal(t, http.StatusBadRequest, request(adminToken, http.MethodPost, Services.FaultInjectionAdminPath, `{"type":"latency","path":"/x"}`).Code)
+	assert.Len(t, injector.List(), 1)
+	assert.Contains(t, request(adminToken, http.MethodGet, Services.FaultInjectionAdminPath, "").Body.String(), created.Data.ID)
+
+	assert.Equal(t, http.StatusNotFound, request(adminToken, http.MethodDelete, Services.FaultInjectionAdminPath+"/missing", "").Code)
+	assert.Equal(t, http.StatusOK, request(adminToken, http.MethodDelete, Services.FaultInjectionAdminPath+"/"+created.Data.ID, "").Code)
+	assert.Empty(t, injector.List())
+
+	_, err := injector.Inject(Services.FaultInjectionInput{Type: Services.FaultError, Path: "/x"}, 1)
+	require.NoError(t, err)
+	w = request(adminToken, http.MethodDelete, Services.FaultInjectionAdminPath, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"removed":1`)
+
+	assert.Equal(t, http.StatusUnauthorized, request("", http.MethodGet, Services.FaultInjectionAdminPath, "").Code)
+	assert.Equal(t, http.StatusForbidden, request(factory.Token(factory.User()), http.MethodGet, Services.FaultInjectionAdminPath, "").Code)
+}
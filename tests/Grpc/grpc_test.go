@@ -0,0 +1,362 @@
+package Grpc
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Grpc"
+	"cloud-platform-api/app/Grpc/Client"
+	"cloud-platform-api/app/Grpc/Proto"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testPKI 测试用CA和由其签发的证书文件
+type testPKI struct {
+	dir    string
+	ca     *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	serial int64
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pki := &testPKI{dir: t.TempDir(), ca: ca, caKey: key, serial: 1}
+	writePEM(t, filepath.Join(pki.dir, "ca.pem"), "CERTIFICATE", der)
+	return pki
+}
+
+// issue 签发证书，返回证书和私钥文件路径
+func (p *testPKI) issue(t *testing.T, name string, server bool) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	p.serial++
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(p.serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if server {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		template.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, p.ca, &key.PublicKey, p.caKey)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile, keyFile := filepath.Join(p.dir, name+".pem"), filepath.Join(p.dir, name+"-key.pem")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600))
+}
+
+// grpcTestEnv 启动的服务端和依赖的服务
+type grpcTestEnv struct {
+	pki      *testPKI
+	server   *httptest.Server
+	address  string
+	db       *gorm.DB
+	core     *Services.MonitoringCore
+	metrics  *Grpc.MetricsInterceptor
+	security *Services.SecurityService
+}
+
+func setupGrpcServer(t *testing.T) *grpcTestEnv {
+	env := &grpcTestEnv{pki: newTestPKI(t)}
+	certFile, keyFile := env.pki.issue(t, "grpc-server", true)
+	config := Config.GrpcConfig{
+		Enabled:        true,
+		Address:        "127.0.0.1:0",
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		ClientCAFile:   filepath.Join(env.pki.dir, "ca.pem"),
+		AllowedClients: "metrics-agent, billing",
+		MaxMessageSize: 64 << 10,
+		RequestTimeout: 5 * time.Second,
+	}
+	require.NoError(t, config.Validate())
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "grpc.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.SecurityEvent{}, &Models.SecurityResponseExecution{}, &Models.AccountLockout{}))
+	env.db = db
+	env.security = Services.NewSecurityService(db, nil)
+	t.Cleanup(env.security.Close)
+
+	monitoringConfig := &Config.MonitoringConfig{}
+	monitoringConfig.SetDefaults()
+	monitoringConfig.Ingest.Enabled = true
+	monitoringConfig.Ingest.MaxBatchSize = 10
+	env.core = Services.NewMonitoringCore()
+	ingest := Services.NewMetricIngestService(monitoringConfig)
+	ingest.SetMonitoringCore(env.core)
+	monitoring := Services.NewOptimizedMonitoringService()
+	monitoring.SetMonitoringCore(env.core)
+
+	server := Grpc.NewServer(config)
+	env.metrics = Grpc.NewMetricsInterceptor(env.core)
+	server.Use(Grpc.TracingInterceptor(), env.metrics.Interceptor())
+	Grpc.NewInternalServices(ingest, monitoring, env.security).Register(server)
+
+	tlsConfig, err := server.TLSConfig()
+	require.NoError(t, err)
+	env.server = httptest.NewUnstartedServer(server)
+	env.server.EnableHTTP2 = true
+	env.server.TLS = tlsConfig
+	env.server.StartTLS()
+	t.Cleanup(env.server.Close)
+	env.address = strings.TrimPrefix(env.server.URL, "https://")
+	return env
+}
+
+// client 使用指定名称的客户端证书创建客户端
+func (env *grpcTestEnv) client(t *testing.T, name string) *Client.Client {
+	certFile, keyFile := env.pki.issue(t, name, false)
+	client, err := Client.NewFromFiles(env.address, certFile, keyFile, filepath.Join(env.pki.dir, "ca.pem"), "")
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+	return client
+}
+
+func TestGrpcMessageRoundTrip(t *testing.T) {
+	request := &Proto.PushMetricsRequest{Samples: []Proto.Sample{
+		{Name: "queue_depth", Value: 12.5, Labels: map[string]string{"queue": "emails", "env": "prod"}, TimestampUnixMs: 1714564800000},
+		{Name: "negative", Value: -3},
+	}}
+	var decoded Proto.PushMetricsRequest
+	require.NoError(t, decoded.Unmarshal(request.Marshal()))
+	assert.Equal(t, request.Samples, decoded.Samples)
+
+	alerts := &Proto.ListAlertsResponse{
+		Alerts:     []Proto.Alert{{ID: "a1", RuleID: "r1", Level: "critical", Value: 95, Threshold: 90, Count: 2, CreatedAtUnixMs: 1714564800000}},
+		NextCursor: "abc",
+		HasMore:    true,
+	}
+	var decodedAlerts Proto.ListAlertsResponse
+	require.NoError(t, decodedAlerts.Unmarshal(alerts.Marshal()))
+	assert.Equal(t, *alerts, decodedAlerts)
+
+	event := &Proto.ReportEventRequest{UserID: 7, EventType: "port_scan", EventLevel: "high", RiskScore: 80, Blocked: true}
+	var decodedEvent Proto.ReportEventRequest
+	require.NoError(t, decodedEvent.Unmarshal(event.Marshal()))
+	assert.Equal(t, *event, decodedEvent)
+
+	assert.Error(t, decodedEvent.Unmarshal([]byte{0x0a, 0x05, 'a'}), "截断的消息")
+}
+
+func TestGrpcEndToEnd(t *testing.T) {
+	env := setupGrpcServer(t)
+	client := env.client(t, "metrics-agent")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// 指标推送共用推送服务的校验，调用方身份作为来源
+	pushed, err := client.PushMetrics(ctx, []Proto.Sample{
+		{Name: "queue_depth", Value: 120, Labels: map[string]string{"queue": "emails"}},
+		{Name: "worker_busy", Value: 95},
+		{Name: "bad-name", Value: 1},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), pushed.Accepted)
+	assert.Equal(t, int32(1), pushed.Rejected)
+	assert.Len(t, pushed.Errors, 1)
+	assert.Equal(t, 120.0, env.core.Values()[`queue_depth{queue="emails"}`])
+
+	_, err = client.PushMetrics(ctx, make([]Proto.Sample, 11))
+	assert.Equal(t, Proto.InvalidArgument, Proto.CodeOf(err), "批次过大")
+
+	// 告警按游标分页
+	for _, metric := range []string{"worker_busy", "disk_usage", "error_rate"} {
+		env.core.Observe(metric, 99)
+		require.NoError(t, env.core.AddRule(&Services.AlertRule{
+			ID: metric, Name: metric, Metric: metric, Condition: ">", Threshold: 90, Level: Services.AlertLevelCritical, Enabled: true,
+		}))
+	}
+	require.NoError(t, env.core.CheckAlerts())
+	var ids []string
+	req := &Proto.ListAlertsRequest{Status: "active", Limit: 2}
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 3)
+		page, err := client.ListAlerts(ctx, req)
+		require.NoError(t, err)
+		for _, alert := range page.Alerts {
+			ids = append(ids, alert.RuleID)
+			assert.Equal(t, "critical", alert.Level)
+			assert.NotZero(t, alert.CreatedAtUnixMs)
+		}
+		if !page.HasMore {
+			break
+		}
+		req.Cursor = page.NextCursor
+	}
+	assert.ElementsMatch(t, []string{"worker_busy", "disk_usage", "error_rate"}, ids)
+
+	_, err = client.ListAlerts(ctx, &Proto.ListAlertsRequest{Cursor: "%%%"})
+	assert.Equal(t, Proto.InvalidArgument, Proto.CodeOf(err))
+
+	// 安全事件写入数据库
+	reported, err := client.ReportSecurityEvent(ctx, &Proto.ReportEventRequest{
+		UserID: 3, EventType: "port_scan", EventLevel: "high", IPAddress: "10.0.0.9", RiskScore: 70,
+	})
+	require.NoError(t, err)
+	assert.True(t, reported.Accepted)
+	var event Models.SecurityEvent
+	require.NoError(t, env.db.Where("event_type = ?", "port_scan").First(&event).Error)
+	assert.Equal(t, "grpc:metrics-agent", event.UserAgent)
+	assert.Equal(t, 70.0, event.RiskScore)
+
+	_, err = client.ReportSecurityEvent(ctx, &Proto.ReportEventRequest{EventType: "port_scan", EventLevel: "urgent"})
+	require.Error(t, err)
+	assert.Equal(t, Proto.InvalidArgument, Proto.CodeOf(err))
+	assert.Contains(t, err.Error(), "无效的事件级别")
+
+	// 调用统计同步到监控指标
+	stats := env.metrics.Stats()
+	assert.Equal(t, int64(3), stats[Proto.MethodListAlerts].Requests, "两页加一次无效游标")
+	assert.Equal(t, int64(1), stats[Proto.MethodReportEvent].Errors)
+	assert.Equal(t, 7.0, env.core.Values()["grpc_requests_total"])
+}
+
+func TestGrpcAuthentication(t *testing.T) {
+	env := setupGrpcServer(t)
+	ctx := context.Background()
+
+	// 证书有效但不在白名单中
+	_, err := env.client(t, "intruder").ListAlerts(ctx, &Proto.ListAlertsRequest{})
+	assert.Equal(t, Proto.PermissionDenied, Proto.CodeOf(err))
+
+	// 没有客户端证书时TLS握手失败
+	caPEM, err := os.ReadFile(filepath.Join(env.pki.dir, "ca.pem"))
+	require.NoError(t, err)
+	rootCAs := x509.NewCertPool()
+	rootCAs.AppendCertsFromPEM(caPEM)
+	_, err = Client.New(env.address, &tls.Config{RootCAs: rootCAs}).ListAlerts(ctx, &Proto.ListAlertsRequest{})
+	assert.Equal(t, Proto.Unavailable, Proto.CodeOf(err))
+
+	// 其他CA签发的客户端证书
+	other := newTestPKI(t)
+	certFile, keyFile := other.issue(t, "metrics-agent", false)
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	require.NoError(t, err)
+	_, err = Client.New(env.address, &tls.Config{RootCAs: rootCAs, Certificates: []tls.Certificate{cert}}).ListAlerts(ctx, &Proto.ListAlertsRequest{})
+	assert.Equal(t, Proto.Unavailable, Proto.CodeOf(err))
+
+	// 未知方法和追踪上下文
+	client := env.client(t, "billing")
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	err = client.Invoke(Client.WithTraceparent(ctx, traceparent), "/cloudplatform.internal.v1.AlertQueryService/DeleteAlerts",
+		&Proto.ListAlertsRequest{}, &Proto.ListAlertsResponse{})
+	assert.Equal(t, Proto.Unimplemented, Proto.CodeOf(err))
+}
+
+func TestGrpcTracingAndTimeout(t *testing.T) {
+	env := setupGrpcServer(t)
+	certFile, keyFile := env.pki.issue(t, "billing", false)
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	require.NoError(t, err)
+	caPEM, err := os.ReadFile(filepath.Join(env.pki.dir, "ca.pem"))
+	require.NoError(t, err)
+	rootCAs := x509.NewCertPool()
+	rootCAs.AppendCertsFromPEM(caPEM)
+	httpClient := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: rootCAs, Certificates: []tls.Certificate{cert}},
+		ForceAttemptHTTP2: true,
+	}}
+	defer httpClient.CloseIdleConnections()
+
+	call := func(headers map[string]string, body string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, env.server.URL+Proto.MethodListAlerts, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/grpc")
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		resp, err := httpClient.Do(req)
+		require.NoError(t, err)
+		// 读完响应体后尾部才可用
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return resp
+	}
+
+	// 沿用调用方的trace-id，生成新的span-id
+	empty := "\x00\x00\x00\x00\x00"
+	resp := call(map[string]string{"Traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}, empty)
+	assert.Equal(t, 2, resp.ProtoMajor)
+	assert.Equal(t, "0", resp.Trailer.Get("Grpc-Status"))
+	traceparent := resp.Header.Get("Traceparent")
+	assert.True(t, strings.HasPrefix(traceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-"))
+	assert.NotContains(t, traceparent, "00f067aa0ba902b7")
+
+	// 无效的traceparent时生成新的trace-id
+	resp = call(map[string]string{"Traceparent": "garbage"}, empty)
+	assert.Regexp(t, `^00-[0-9a-f]{32}-[0-9a-f]{16}-01$`, resp.Header.Get("Traceparent"))
+
+	// 无效的超时和超过大小限制的消息
+	resp = call(map[string]string{"Grpc-Timeout": "5x"}, empty)
+	assert.Equal(t, "3", resp.Trailer.Get("Grpc-Status"))
+	resp = call(nil, "\x00\x01\x00\x00\x00")
+	assert.Equal(t, "8", resp.Trailer.Get("Grpc-Status"))
+	resp = call(nil, "\x01\x00\x00\x00\x00")
+	assert.Equal(t, "12", resp.Trailer.Get("Grpc-Status"))
+}
+
+func TestGrpcConfigValidate(t *testing.T) {
+	config := Config.GrpcConfig{}
+	assert.NoError(t, config.Validate(), "未启用时不检查")
+
+	config = Config.GrpcConfig{Enabled: true, Address: ":9090", CertFile: "server.pem", KeyFile: "server-key.pem", MaxMessageSize: 1024, RequestTimeout: time.Second}
+	assert.ErrorContains(t, config.Validate(), "客户端CA")
+	config.ClientCAFile = "ca.pem"
+	assert.NoError(t, config.Validate())
+
+	config.AllowedClients = " metrics-agent, ,billing "
+	assert.Equal(t, []string{"metrics-agent", "billing"}, config.AllowedClientNames())
+}
@@ -0,0 +1,145 @@
+package I18n
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/I18n"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslator(t *testing.T) {
+	translator, err := I18n.NewTranslatorWithBuiltin("zh-CN", "en")
+	require.NoError(t, err)
+
+	t.Run("内置语言包", func(t *testing.T) {
+		assert.Contains(t, translator.Locales(), "zh-CN")
+		assert.Contains(t, translator.Locales(), "en")
+		assert.Equal(t, "登录成功", translator.Translate("zh-CN", "auth.login_success", nil))
+		assert.Equal(t, "Login successful", translator.Translate("en", "auth.login_success", nil))
+	})
+
+	t.Run("地区和主语言回退", func(t *testing.T) {
+		assert.Equal(t, "Login successful", translator.Translate("en-US", "auth.login_success", nil))
+		assert.Equal(t, "登录成功", translator.Translate("zh-TW", "auth.login_success", nil))
+		assert.Equal(t, "登录成功", translator.Translate("", "auth.login_success", nil))
+	})
+
+	t.Run("缺失键返回原文", func(t *testing.T) {
+		assert.Equal(t, "未迁移的文本", translator.Translate("en", "未迁移的文本", nil))
+	})
+
+	t.Run("命名参数", func(t *testing.T) {
+		translator.AddMessage("en", "test.greeting", "Hello :name, you have :name_count messages")
+		text := translator.Translate("en", "test.greeting", I18n.Params{"name": "Alice", "name_count": 3})
+		assert.Equal(t, "Hello Alice, you have 3 messages", text)
+	})
+
+	t.Run("复数形式", func(t *testing.T) {
+		assert.Equal(t, "1 item", translator.Choice("en", "common.items", 1, nil))
+		assert.Equal(t, "5 items", translator.Choice("en", "common.items", 5, nil))
+		assert.Equal(t, "5 条记录", translator.Choice("zh-CN", "common.items", 5, nil))
+	})
+}
+
+func TestPluralRules(t *testing.T) {
+	cases := []struct {
+		locale   string
+		count    int64
+		expected I18n.PluralCategory
+	}{
+		{"en", 1, I18n.PluralOne},
+		{"en", 0, I18n.PluralOther},
+		{"zh-CN", 1, I18n.PluralOther},
+		{"fr", 0, I18n.PluralOne},
+		{"ru", 1, I18n.PluralOne},
+		{"ru", 3, I18n.PluralFew},
+		{"ru", 11, I18n.PluralMany},
+		{"ru", 22, I18n.PluralFew},
+	}
+
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("%s_%d", tc.locale, tc.count), func(t *testing.T) {
+			assert.Equal(t, tc.expected, I18n.PluralCategoryFor(tc.locale, tc.count))
+		})
+	}
+}
+
+func TestLocaleNegotiation(t *testing.T) {
+	t.Run("解析Accept-Language", func(t *testing.T) {
+		languages := I18n.ParseAcceptLanguage("en;q=0.8, zh-cn, *;q=0.1, fr;q=0")
+		assert.Equal(t, []string{"zh-CN", "en"}, languages)
+	})
+
+	t.Run("匹配支持的语言", func(t *testing.T) {
+		supported := []string{"zh-CN", "en"}
+		assert.Equal(t, "en", I18n.MatchLocale([]string{"en-GB"}, supported))
+		assert.Equal(t, "zh-CN", I18n.MatchLocale([]string{"ja", "zh-TW"}, supported))
+		assert.Equal(t, "", I18n.MatchLocale([]string{"ja"}, supported))
+	})
+
+	t.Run("显式语言优先于用户偏好", func(t *testing.T) {
+		I18n.SetUserPreferenceResolver(func(userID string) string { return "en" })
+		defer I18n.SetUserPreferenceResolver(nil)
+
+		supported := []string{"zh-CN", "en"}
+		assert.Equal(t, "zh-CN", I18n.Negotiate(I18n.LocaleRequest{Explicit: "zh-CN", UserID: "1"}, supported))
+		assert.Equal(t, "en", I18n.Negotiate(I18n.LocaleRequest{UserID: "1", AcceptLanguage: "zh-CN"}, supported))
+	})
+}
+
+func TestCatalogLoading(t *testing.T) {
+	dir := t.TempDir()
+	yamlCatalog := "auth:\n  login_success: Signed in\nreport:\n  pages:\n    one: \":count page\"\n    other: \":count pages\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "en.yaml"), []byte(yamlCatalog), 0644))
+
+	translator, err := I18n.NewTranslatorWithBuiltin("zh-CN", "en")
+	require.NoError(t, err)
+	require.NoError(t, translator.LoadDir(dir))
+
+	assert.Equal(t, "Signed in", translator.Translate("en", "auth.login_success", nil))
+	assert.Equal(t, "2 pages", translator.Choice("en", "report.pages", 2, nil))
+	// 外部语言包只覆盖同名键
+	assert.Equal(t, "Login failed", translator.Translate("en", "auth.login_failed", nil))
+}
+
+func TestLocalizeError(t *testing.T) {
+	translator, err := I18n.NewTranslatorWithBuiltin("zh-CN", "en")
+	require.NoError(t, err)
+	I18n.SetTranslator(translator)
+
+	err = I18n.NewError("auth.invalid_credentials", "invalid credentials")
+	assert.Equal(t, "invalid credentials", err.Error())
+	assert.Equal(t, "用户名或密码错误", I18n.LocalizeError("zh-CN", err))
+
+	wrapped := fmt.Errorf("login: %w", err)
+	assert.Equal(t, "Invalid credentials", I18n.LocalizeError("en", wrapped))
+	assert.Equal(t, "plain", I18n.LocalizeError("en", errors.New("plain")))
+}
+
+func TestControllerLocaleResolvesPreferenceOncePerRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	calls := 0
+	I18n.SetUserPreferenceResolver(func(userID string) string {
+		calls++
+		return "en"
+	})
+	defer I18n.SetUserPreferenceResolver(nil)
+
+	controller := &Controllers.Controller{}
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx.Set("user_id", "1")
+
+	assert.Equal(t, "en", controller.Locale(ctx))
+	assert.Equal(t, "Login successful", controller.Trans(ctx, "auth.login_success"))
+	assert.Equal(t, 1, calls, "同一请求只查询一次用户偏好")
+}
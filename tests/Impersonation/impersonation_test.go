@@ -0,0 +1,206 @@
+package Impersonation
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Http/Routes"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"cloud-platform-api/app/Testing"
+	"cloud-platform-api/app/Utils"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func setupDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "impersonation.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.User{}, &Models.ImpersonationSession{}, &Models.AuditLog{}, &Models.Notification{}))
+	return db
+}
+
+func loadConfig(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-jwt-secret-key-for-testing-only-32-chars")
+	Config.LoadConfig()
+}
+
+func TestStartImpersonationRules(t *testing.T) {
+	loadConfig(t)
+	db := setupDB(t)
+	factory := Testing.NewFactory(t, db)
+	service := Services.NewImpersonationService(db, &Config.ImpersonationConfig{
+		Enabled: true, DefaultTTL: 10 * time.Minute, MaxTTL: time.Hour,
+	})
+
+	admin := factory.Admin()
+	otherAdmin := factory.Admin()
+	disabled := factory.User()
+	require.NoError(t, db.Model(disabled).Update("status", 0).Error)
+	user := factory.User()
+
+	for _, targetID := range []uint{admin.ID, otherAdmin.ID, disabled.ID} {
+		_, err := service.Start(admin.ID, Services.ImpersonationRequest{TargetUserID: targetID, Reason: "排查"})
+		assert.ErrorIs(t, err, Services.ErrImpersonationForbidden, "目标用户 %d", targetID)
+	}
+	_, err := service.Start(admin.ID, Services.ImpersonationRequest{TargetUserID: 9999, Reason: "排查"})
+	assert.ErrorIs(t, err, Services.ErrImpersonationUserNotFound)
+	_, err = service.Start(admin.ID, Services.ImpersonationRequest{TargetUserID: user.ID, Reason: "排查", Duration: 2 * time.Hour})
+	assert.ErrorIs(t, err, Services.ErrImpersonationDuration)
+
+	now := time.Now()
+	service.SetClock(func() time.Time { return now })
+	result, err := service.Start(admin.ID, Services.ImpersonationRequest{TargetUserID: user.ID, Reason: "排查"})
+	require.NoError(t, err)
+	assert.WithinDuration(t, now.Add(10*time.Minute), result.Session.ExpiresAt, time.Second)
+
+	claims, err := Utils.NewJWTUtils(&Config.GetConfig().JWT).ValidateToken(result.Token)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+	assert.Equal(t, "user", claims.Role)
+	assert.Equal(t, admin.ID, claims.ImpersonatorID)
+	assert.Equal(t, result.Session.SessionID, claims.ImpersonationID)
+	_, err = Utils.NewJWTUtils(&Config.GetConfig().JWT).RefreshToken(result.Token)
+	assert.Error(t, err, "模拟令牌不能刷新")
+
+	// 过期后会话失效
+	service.SetClock(func() time.Time { return now.Add(11 * time.Minute) })
+	_, err = service.ActiveSession(result.Session.SessionID)
+	assert.ErrorIs(t, err, Services.ErrImpersonationEnded)
+
+	disabledService := Services.NewImpersonationService(db, &Config.ImpersonationConfig{})
+	_, err = disabledService.Start(admin.ID, Services.ImpersonationRequest{TargetUserID: user.ID, Reason: "排查"})
+	assert.ErrorIs(t, err, Services.ErrImpersonationDisabled)
+}
+
+func TestImpersonationAPI(t *testing.T) {
+	loadConfig(t)
+	OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "测试", Version: "1.0.0"}))
+	db := setupDB(t)
+	service := Services.NewImpersonationService(db, &Config.ImpersonationConfig{
+		Enabled:          true,
+		DefaultTTL:       30 * time.Minute,
+		MaxTTL:           time.Hour,
+		RestrictedRoutes: "POST /api/v1/auth/change-password,* /api/v1/api-keys*",
+	})
+	Services.SetDefaultImpersonationService(service)
+	t.Cleanup(func() {
+		Services.SetDefaultImpersonationService(nil)
+		OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "Cloud Platform API", Version: "1.0.0"}))
+	})
+
+	engine := gin.New()
+	Routes.RegisterImpersonationRoutes(engine, Storage.NewStorageManager(&Config.StorageConfig{BasePath: t.TempDir()}),
+		Controllers.NewImpersonationController(service))
+	userGroup := engine.Group("/api/v1", Middleware.NewAuthMiddleware().Handle())
+	ok := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.GetString("user_id"), "impersonator": c.GetString("impersonator")})
+	}
+	userGroup.GET("/profile", ok)
+	userGroup.POST("/posts", ok)
+	userGroup.POST("/auth/change-password", ok)
+	userGroup.POST("/api-keys", ok)
+
+	factory := Testing.NewFactory(t, db)
+	admin := factory.Admin()
+	user := factory.User()
+	adminToken := factory.Token(admin)
+	request := func(token, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+	start := func(body string) Services.ImpersonationResult {
+		w := request(adminToken, http.MethodPost, "/api/v1/admin/impersonations", body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var resp struct {
+			Data Services.ImpersonationResult `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Data
+	}
+
+	assert.Equal(t, http.StatusBadRequest, request(adminToken, http.MethodPost, "/api/v1/admin/impersonations",
+		fmt.Sprintf(`{"user_id":%d}`, user.ID)).Code, "必须填写原因")
+	assert.Equal(t, http.StatusForbidden, request(factory.Token(user), http.MethodPost, "/api/v1/admin/impersonations",
+		fmt.Sprintf(`{"user_id":%d,"reason":"排查"}`, user.ID)).Code)
+
+	result := start(fmt.Sprintf(`{"user_id":%d,"reason":"复现工单问题"}`, user.ID))
+	w := request(result.Token, http.MethodGet, "/api/v1/profile", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, admin.Username, w.Header().Get("X-Impersonated-By"))
+	assert.Contains(t, w.Body.String(), fmt.Sprintf(`"user_id":"%d"`, user.ID))
+	assert.Equal(t, http.StatusOK, request(result.Token, http.MethodPost, "/api/v1/posts", "").Code)
+	assert.Equal(t, http.StatusForbidden, request(result.Token, http.MethodPost, "/api/v1/auth/change-password", "").Code)
+	assert.Equal(t, http.StatusForbidden, request(result.Token, http.MethodPost, "/api/v1/api-keys", "").Code)
+	assert.Equal(t, http.StatusForbidden, request(result.Token, http.MethodGet, "/api/v1/admin/impersonations", "").Code)
+
+	// 每个请求（包括被拒绝的）都记录带模拟管理员标记的审计日志
+	var logs []Models.AuditLog
+	require.NoError(t, db.Where("action = ?", Models.AuditActionImpersonationRequest).Order("id").Find(&logs).Error)
+	require.Len(t, logs, 5)
+	for _, entry := range logs {
+		assert.Equal(t, user.ID, entry.UserID)
+		assert.Equal(t, admin.ID, entry.ImpersonatorID)
+		assert.Contains(t, entry.Metadata, result.Session.SessionID)
+	}
+	assert.Equal(t, Models.AuditStatusFailed, logs[2].Status)
+	var started int64
+	require.NoError(t, db.Model(&Models.AuditLog{}).Where("action = ? AND user_id = ?", Models.AuditActionImpersonationStart, admin.ID).Count(&started).Error)
+	assert.Equal(t, int64(1), started)
+	var notifications []Models.Notification
+	require.NoError(t, db.Where("user_id = ? AND type = ?", user.ID, Models.NotificationTypeImpersonation).Find(&notifications).Error)
+	assert.Len(t, notifications, 1)
+
+	// 只读会话只允许查询
+	readOnly := start(fmt.Sprintf(`{"user_id":%d,"reason":"只读排查","read_only":true,"duration_minutes":5}`, user.ID))
+	assert.Equal(t, http.StatusOK, request(readOnly.Token, http.MethodGet, "/api/v1/profile", "").Code)
+	assert.Equal(t, http.StatusForbidden, request(readOnly.Token, http.MethodPost, "/api/v1/posts", "").Code)
+	assert.Equal(t, http.StatusBadRequest, request(adminToken, http.MethodPost, "/api/v1/admin/impersonations",
+		fmt.Sprintf(`{"user_id":%d,"reason":"排查","duration_minutes":120}`, user.ID)).Code)
+
+	// 结束会话后模拟令牌立即失效
+	endPath := fmt.Sprintf("/api/v1/admin/impersonations/%d", result.Session.ID)
+	assert.Equal(t, http.StatusOK, request(adminToken, http.MethodDelete, endPath, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, request(result.Token, http.MethodGet, "/api/v1/profile", "").Code)
+	assert.Equal(t, http.StatusConflict, request(adminToken, http.MethodDelete, endPath, "").Code)
+	assert.Equal(t, http.StatusNotFound, request(adminToken, http.MethodDelete, "/api/v1/admin/impersonations/9999", "").Code)
+
+	var session Models.ImpersonationSession
+	require.NoError(t, db.First(&session, result.Session.ID).Error)
+	assert.Equal(t, int64(5), session.RequestCount)
+	assert.Equal(t, admin.ID, session.EndedBy)
+
+	w = request(adminToken, http.MethodGet, "/api/v1/admin/impersonations?active=true", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var list struct {
+		Data []Models.ImpersonationSession `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, readOnly.Session.ID, list.Data[0].ID)
+}
@@ -0,0 +1,245 @@
+package Inspector
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Http/Routes"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"cloud-platform-api/app/Testing"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func inspectorConfig() *Config.InspectorConfig {
+	return &Config.InspectorConfig{
+		Enabled:       true,
+		Environments:  "development,testing",
+		SampleRate:    1,
+		IgnorePaths:   "/health,/api/v1/admin/inspector",
+		MaxBodySize:   1024,
+		Retention:     time.Hour,
+		MaxEntries:    100,
+		MaxOperations: 50,
+		QueueSize:     100,
+	}
+}
+
+func maskingConfig() Config.LogMaskingConfig {
+	logConfig := &Config.LogConfig{}
+	logConfig.SetDefaults()
+	return logConfig.Masking
+}
+
+// setupDB 使用日志管理器的GORM日志适配器打开测试数据库，语句经过 Trace 写入记录器
+func setupDB(t *testing.T) *gorm.DB {
+	logConfig := &Config.LogConfig{}
+	logConfig.SetDefaults()
+	logConfig.BasePath = t.TempDir()
+	manager := Services.NewLogManagerService(logConfig)
+	t.Cleanup(func() { manager.Close() })
+
+	gormLogger := manager.GormLogger().(*Services.GormLogger)
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "inspector.db")), &gorm.Config{Logger: gormLogger})
+	require.NoError(t, err)
+	require.NoError(t, gormLogger.Install(db))
+	require.NoError(t, db.AutoMigrate(&Models.User{}, &Models.InspectorEntry{}))
+	return db
+}
+
+func TestInspectorConfigValidate(t *testing.T) {
+	assert.NoError(t, (&Config.InspectorConfig{}).Validate(), "未启用时不检查")
+	assert.NoError(t, inspectorConfig().Validate())
+
+	for _, modify := range []func(*Config.InspectorConfig){
+		func(c *Config.InspectorConfig) { c.SampleRate = 0 },
+		func(c *Config.InspectorConfig) { c.SampleRate = 1.5 },
+		func(c *Config.InspectorConfig) { c.UserIDs = "1,abc" },
+		func(c *Config.InspectorConfig) { c.IgnorePaths = "health" },
+		func(c *Config.InspectorConfig) { c.MaxBodySize = -1 },
+		func(c *Config.InspectorConfig) { c.Retention = 0 },
+		func(c *Config.InspectorConfig) { c.MaxEntries = 0 },
+		func(c *Config.InspectorConfig) { c.MaxOperations = 0 },
+		func(c *Config.InspectorConfig) { c.QueueSize = 0 },
+	} {
+		config := inspectorConfig()
+		modify(config)
+		assert.Error(t, config.Validate())
+	}
+
+	config := inspectorConfig()
+	config.UserIDs = " 3, 5 "
+	ids, err := config.UserIDList()
+	require.NoError(t, err)
+	assert.Equal(t, []uint{3, 5}, ids)
+}
+
+func TestInspectorSamplingByEnvironmentAndUser(t *testing.T) {
+	config := inspectorConfig()
+	config.UserIDs = "7"
+	config.SampleRate = 0.5
+
+	inspector, err := Services.NewRequestInspector(nil, config, maskingConfig(), "Development")
+	require.NoError(t, err)
+	inspector.SetRandom(func() float64 { return 0.3 })
+	assert.True(t, inspector.Sample())
+	inspector.SetRandom(func() float64 { return 0.7 })
+	assert.False(t, inspector.Sample(), "未命中采样率")
+	assert.True(t, inspector.ShouldRecord(false, "7"), "指定用户总是记录")
+	assert.False(t, inspector.ShouldRecord(false, "8"))
+
+	production, err := Services.NewRequestInspector(nil, config, maskingConfig(), "production")
+	require.NoError(t, err)
+	production.SetRandom(func() float64 { return 0 })
+	assert.False(t, production.Sample(), "生产环境不对所有用户采样")
+	assert.True(t, production.Watching())
+
+	assert.True(t, production.Ignored("/health"))
+	assert.True(t, production.Ignored("/api/v1/admin/inspector/requests"))
+	assert.False(t, production.Ignored("/healthz"), "按路径段匹配")
+}
+
+func TestInspectorRecordsRequests(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-jwt-secret-key-for-testing-only-32-chars")
+	Config.LoadConfig()
+	OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "测试", Version: "1.0.0"}))
+	t.Cleanup(func() {
+		OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "Cloud Platform API", Version: "1.0.0"}))
+	})
+
+	db := setupDB(t)
+	factory := Testing.NewFactory(t, db)
+	admin := factory.Admin()
+	adminToken := factory.Token(admin)
+
+	config := inspectorConfig()
+	inspector, err := Services.NewRequestInspector(db, config, maskingConfig(), "testing")
+	require.NoError(t, err)
+
+	storageManager := Storage.NewStorageManager(&Config.StorageConfig{BasePath: t.TempDir()})
+	engine := gin.New()
+	engine.Use(Middleware.NewRequestIDMiddleware().Handle())
+	engine.Use(gin.Recovery())
+	engine.Use(Middleware.NewInspectorMiddleware(inspector, config.MaxOperations).Handle())
+	engine.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+	api := engine.Group("/api/v1/things")
+	api.Use(Middleware.NewAuthMiddleware().Handle())
+	api.POST("", func(c *gin.Context) {
+		var input map[string]interface{}
+		require.NoError(t, c.ShouldBindJSON(&input))
+		var users []Models.User
+		db.WithContext(c.Request.Context()).Where("id = ?", admin.ID).Find(&users)
+		c.JSON(http.StatusCreated, gin.H{"success": true, "name": input["name"]})
+	})
+	api.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+	Routes.RegisterInspectorRoutes(engine, storageManager, Controllers.NewInspectorController(inspector))
+
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		inspector.Flush()
+		return w
+	}
+
+	w := request(http.MethodPost, "/api/v1/things", `{"name":"widget","password":"secret-value"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "widget", "请求体被完整传给处理器")
+	assert.Equal(t, http.StatusInternalServerError, request(http.MethodGet, "/api/v1/things/panic", "").Code)
+	request(http.MethodGet, "/health", "")
+
+	var count int64
+	db.Model(&Models.InspectorEntry{}).Count(&count)
+	assert.Equal(t, int64(2), count, "不记录的路由分组和检查器接口本身不写入")
+
+	var entry Models.InspectorEntry
+	require.NoError(t, db.Where("method = ?", http.MethodPost).First(&entry).Error)
+	assert.Equal(t, "/api/v1/things", entry.Route)
+	require.NotNil(t, entry.UserID)
+	assert.Equal(t, admin.ID, *entry.UserID)
+	assert.NotContains(t, entry.RequestBody, "secret-value", "请求体按脱敏规则处理")
+	assert.NotContains(t, entry.RequestHeaders, adminToken, "认证头按脱敏规则处理")
+	assert.GreaterOrEqual(t, entry.QueryCount, 1)
+
+	w = request(http.MethodGet, "/api/v1/admin/inspector/requests/"+strconv.Itoa(int(entry.ID)), "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var detail struct {
+		Data struct {
+			StatusCode   int                      `json:"status_code"`
+			RequestBody  map[string]interface{}   `json:"request_body"`
+			ResponseBody map[string]interface{}   `json:"response_body"`
+			SQL          []map[string]interface{} `json:"sql"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &detail))
+	assert.Equal(t, http.StatusCreated, detail.Data.StatusCode)
+	assert.Equal(t, "widget", detail.Data.RequestBody["name"])
+	assert.Equal(t, "widget", detail.Data.ResponseBody["name"])
+	assert.NotEmpty(t, detail.Data.SQL)
+
+	w = request(http.MethodGet, "/api/v1/admin/inspector/requests?filter[has_exception]=true", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var list struct {
+		Data []Models.InspectorEntry `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, http.StatusInternalServerError, list.Data[0].StatusCode)
+	assert.Contains(t, list.Data[0].Exception, "panic: boom")
+
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodGet, "/api/v1/admin/inspector/requests?filter[exception]=x", "").Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/api/v1/admin/inspector/requests/999", "").Code)
+	assert.Equal(t, int64(2), inspector.Stats().Recorded)
+}
+
+func TestInspectorPurge(t *testing.T) {
+	db := setupDB(t)
+	config := inspectorConfig()
+	config.MaxEntries = 3
+	inspector, err := Services.NewRequestInspector(db, config, maskingConfig(), "testing")
+	require.NoError(t, err)
+
+	now := time.Now()
+	entries := []Models.InspectorEntry{{Path: "/old", CreatedAt: now.Add(-2 * time.Hour)}}
+	for i := 0; i < 5; i++ {
+		entries = append(entries, Models.InspectorEntry{Path: "/new", CreatedAt: now.Add(time.Duration(i) * time.Second)})
+	}
+	require.NoError(t, db.Create(&entries).Error)
+
+	deleted, err := inspector.Purge(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), deleted, "删除过期记录和超出数量上限的最早记录")
+
+	var remaining []Models.InspectorEntry
+	require.NoError(t, db.Order("id").Find(&remaining).Error)
+	require.Len(t, remaining, 3)
+	assert.Equal(t, entries[3].ID, remaining[0].ID)
+
+	removed, err := inspector.Clear()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), removed)
+}
@@ -0,0 +1,272 @@
+package Listeners
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Services"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listenersConfig 测试使用的完整配置
+func listenersConfig() *Config.Config {
+	config := &Config.Config{}
+	config.Server.Port = "8080"
+	config.Monitoring.BaseConfig.EnableDashboard = true
+	config.Monitoring.BaseConfig.DashboardPort = 8081
+	config.Monitoring.BaseConfig.MetricsPort = 8082
+	config.Listeners = Config.ServerListenersConfig{
+		AdminEnabled:    true,
+		AdminPrefixes:   "/api/v1/admin, /debug/pprof/",
+		Admin:           Config.ListenerConfig{Port: "127.0.0.1:8083", AllowedIPs: "127.0.0.1,10.0.0.0/8"},
+		MetricsEnabled:  true,
+		ShutdownTimeout: time.Second,
+	}
+	return config
+}
+
+func TestListenersConfigValidate(t *testing.T) {
+	config := listenersConfig()
+	require.NoError(t, config.Listeners.Validate())
+	require.NoError(t, Config.ValidateListeners(config))
+	assert.Equal(t, []string{"/api/v1/admin", "/debug/pprof"}, config.Listeners.AdminPrefixList())
+	assert.Equal(t, map[string]string{
+		Config.ListenerAPI:       ":8080",
+		Config.ListenerAdmin:     "127.0.0.1:8083",
+		Config.ListenerMetrics:   ":8082",
+		Config.ListenerDashboard: ":8081",
+	}, Config.ListenerAddrs(config))
+
+	networks, err := config.Listeners.Admin.AllowedNetworks()
+	require.NoError(t, err)
+	assert.Len(t, networks, 2)
+
+	invalid := map[string]func(c *Config.Config){
+		"root prefix":      func(c *Config.Config) { c.Listeners.AdminPrefixes = "/" },
+		"bad cidr":         func(c *Config.Config) { c.Listeners.Metrics.AllowedIPs = "10.0.0.0/33" },
+		"dashboard token":  func(c *Config.Config) { c.Listeners.Dashboard.AuthToken = "secret" },
+		"cert without key": func(c *Config.Config) { c.Listeners.Admin.TLS = true; c.Listeners.Admin.CertFile = "a.crt" },
+		"cert without tls": func(c *Config.Config) { c.Listeners.Admin.CertFile = "a.crt"; c.Listeners.Admin.KeyFile = "a.key" },
+		"shutdown timeout": func(c *Config.Config) { c.Listeners.ShutdownTimeout = 0 },
+		"port conflict":    func(c *Config.Config) { c.Listeners.Metrics.Port = "8080" },
+		"redirect conflict": func(c *Config.Config) {
+			c.TLS = Config.ServerTLSConfig{Enabled: true, RedirectHTTP: true, HTTPPort: "8081"}
+		},
+		"inherit without tls": func(c *Config.Config) { c.Listeners.Metrics.TLS = true },
+	}
+	for name, mutate := range invalid {
+		config := listenersConfig()
+		mutate(config)
+		err := config.Listeners.Validate()
+		if err == nil {
+			err = Config.ValidateListeners(config)
+		}
+		assert.Error(t, err, name)
+	}
+
+	// 未启用的端口不参与冲突检查
+	config = listenersConfig()
+	config.Listeners.MetricsEnabled = false
+	config.Monitoring.BaseConfig.MetricsPort = 8080
+	assert.NoError(t, Config.ValidateListeners(config))
+}
+
+// engine 模拟Gin引擎，返回请求路径
+func engine() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write([]byte(r.URL.Path))
+	})
+}
+
+// get 发送请求，返回状态码和响应体
+func get(t *testing.T, client *http.Client, url string, headers map[string]string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestServerManagerRoutesEachListener(t *testing.T) {
+	admin := []string{"/api/v1/admin", "/debug/pprof"}
+	loopback, err := (&Config.ListenerConfig{AllowedIPs: "127.0.0.1"}).AllowedNetworks()
+	require.NoError(t, err)
+	remote, err := (&Config.ListenerConfig{AllowedIPs: "192.0.2.0/24"}).AllowedNetworks()
+	require.NoError(t, err)
+
+	public := Services.RouteFilter(Services.RouteFilter(engine(), admin, false), []string{"/metrics"}, false)
+	manager := Services.NewServerManager()
+	manager.Add(Config.ListenerAPI, &http.Server{Addr: "127.0.0.1:0", Handler: public})
+	manager.Add(Config.ListenerAdmin, &http.Server{Addr: "127.0.0.1:0", Handler: Services.ListenerGuard(Services.RouteFilter(engine(), admin, true), loopback, "")})
+	manager.Add(Config.ListenerMetrics, &http.Server{Addr: "127.0.0.1:0", Handler: Services.ListenerGuard(Services.RouteFilter(engine(), []string{"/metrics"}, true), nil, "scrape-token")})
+	manager.Add("restricted", &http.Server{Addr: "127.0.0.1:0", Handler: Services.ListenerGuard(engine(), remote, "")})
+	require.NoError(t, manager.Start())
+	defer manager.Shutdown(context.Background())
+	assert.Equal(t, []string{Config.ListenerAPI, Config.ListenerAdmin, Config.ListenerMetrics, "restricted"}, manager.Names())
+
+	url := func(name, path string) string { return "http://" + manager.Addr(name).String() + path }
+	client := &http.Client{}
+	cases := []struct {
+		url     string
+		headers map[string]string
+		status  int
+	}{
+		{url(Config.ListenerAPI, "/api/v1/users"), nil, http.StatusOK},
+		{url(Config.ListenerAPI, "/api/v1/admin/stats"), nil, http.StatusNotFound},
+		{url(Config.ListenerAPI, "/api/v1/administrators"), nil, http.StatusOK},
+		{url(Config.ListenerAPI, "/metrics"), nil, http.StatusNotFound},
+		{url(Config.ListenerAdmin, "/api/v1/admin/stats"), nil, http.StatusOK},
+		{url(Config.ListenerAdmin, "/debug/pprof/heap"), nil, http.StatusOK},
+		{url(Config.ListenerAdmin, "/api/v1/users"), nil, http.StatusNotFound},
+		{url(Config.ListenerMetrics, "/metrics"), nil, http.StatusUnauthorized},
+		{url(Config.ListenerMetrics, "/metrics"), map[string]string{"Authorization": "Bearer wrong"}, http.StatusUnauthorized},
+		{url(Config.ListenerMetrics, "/metrics"), map[string]string{"Authorization": "Bearer scrape-token"}, http.StatusOK},
+		{url(Config.ListenerMetrics, "/api/v1/users"), map[string]string{"Authorization": "Bearer scrape-token"}, http.StatusNotFound},
+		{url("restricted", "/api/v1/users"), nil, http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		status, _ := get(t, client, tc.url, tc.headers)
+		assert.Equal(t, tc.status, status, tc.url)
+	}
+}
+
+func TestServerManagerStartFailsWhenPortInUse(t *testing.T) {
+	occupied, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer occupied.Close()
+
+	manager := Services.NewServerManager()
+	manager.Add(Config.ListenerAPI, &http.Server{Addr: "127.0.0.1:0", Handler: engine()})
+	manager.Add(Config.ListenerMetrics, &http.Server{Addr: occupied.Addr().String(), Handler: engine()})
+	err = manager.Start()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), Config.ListenerMetrics)
+	assert.Nil(t, manager.Addr(Config.ListenerAPI), "已监听的端口被关闭")
+	assert.NoError(t, manager.Shutdown(context.Background()))
+}
+
+func TestServerManagerGracefulShutdown(t *testing.T) {
+	manager := Services.NewServerManager()
+	manager.Add(Config.ListenerAPI, &http.Server{Addr: "127.0.0.1:0", Handler: engine()})
+	manager.Add(Config.ListenerAdmin, &http.Server{Addr: "127.0.0.1:0", Handler: engine()})
+	require.NoError(t, manager.Start())
+	apiAddr := manager.Addr(Config.ListenerAPI).String()
+
+	// 关闭时进行中的请求正常完成
+	done := make(chan int, 1)
+	go func() {
+		status, _ := get(t, &http.Client{}, "http://"+apiAddr+"/slow", nil)
+		done <- status
+	}()
+	time.Sleep(50 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, manager.Shutdown(ctx))
+	assert.Equal(t, http.StatusOK, <-done)
+
+	for _, name := range []string{Config.ListenerAPI, Config.ListenerAdmin} {
+		_, err := net.DialTimeout("tcp", manager.Addr(name).String(), 100*time.Millisecond)
+		assert.Error(t, err, name)
+	}
+	select {
+	case err := <-manager.Errors():
+		t.Fatalf("正常关闭不应报告错误: %v", err)
+	default:
+	}
+}
+
+// writeCertificate 在临时目录生成自签名证书
+func writeCertificate(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "internal"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+func TestListenerTLSConfig(t *testing.T) {
+	certFile, keyFile := writeCertificate(t)
+	apiConfig := &Config.ServerTLSConfig{Enabled: true, CertFile: certFile, KeyFile: keyFile, MinVersion: "1.3", HTTP2: true}
+	apiTLS, err := Services.NewServerTLS(apiConfig, "8443")
+	require.NoError(t, err)
+
+	// 未启用TLS
+	config, reloader, err := Services.ListenerTLSConfig(&Config.ListenerConfig{}, apiTLS, apiConfig)
+	require.NoError(t, err)
+	assert.Nil(t, config)
+	assert.Nil(t, reloader)
+
+	// 复用API端口的证书
+	config, reloader, err = Services.ListenerTLSConfig(&Config.ListenerConfig{TLS: true}, apiTLS, apiConfig)
+	require.NoError(t, err)
+	assert.Nil(t, reloader)
+	assert.Equal(t, uint16(tls.VersionTLS13), config.MinVersion)
+	_, _, err = Services.ListenerTLSConfig(&Config.ListenerConfig{TLS: true}, nil, &Config.ServerTLSConfig{})
+	assert.Error(t, err)
+
+	// 独立证书，API端口未启用TLS时使用 TLS_* 的协议设置
+	config, reloader, err = Services.ListenerTLSConfig(&Config.ListenerConfig{TLS: true, CertFile: certFile, KeyFile: keyFile}, nil, &Config.ServerTLSConfig{MinVersion: "1.2", HTTP2: true})
+	require.NoError(t, err)
+	require.NotNil(t, reloader)
+	defer reloader.Close()
+
+	manager := Services.NewServerManager()
+	manager.Add(Config.ListenerMetrics, &http.Server{Addr: "127.0.0.1:0", Handler: engine(), TLSConfig: config})
+	require.NoError(t, manager.Start())
+	defer manager.Shutdown(context.Background())
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+		ForceAttemptHTTP2: true,
+	}}
+	resp, err := client.Get("https://" + manager.Addr(Config.ListenerMetrics).String() + "/metrics")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, resp.ProtoMajor)
+
+	// 明文请求被拒绝
+	status, _ := get(t, &http.Client{}, "http://"+manager.Addr(Config.ListenerMetrics).String()+"/metrics", nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+}
@@ -0,0 +1,134 @@
+package LoadTest
+
+import (
+	"cloud-platform-api/app/Testing/LoadTest"
+	"context"
+	"net/http"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestGeneratorAgainstHandler(t *testing.T) {
+	var calls int64
+	engine := gin.New()
+	engine.GET("/fast", func(c *gin.Context) {
+		atomic.AddInt64(&calls, 1)
+		c.Status(http.StatusOK)
+	})
+	engine.GET("/slow", func(c *gin.Context) {
+		time.Sleep(2 * time.Millisecond)
+		c.Status(http.StatusOK)
+	})
+	engine.POST("/login", func(c *gin.Context) {
+		if c.GetHeader("Content-Type") != "application/json" {
+			c.Status(http.StatusUnsupportedMediaType)
+			return
+		}
+		c.Status(http.StatusUnauthorized)
+	})
+
+	generator := LoadTest.NewGenerator(engine, LoadTest.Options{Concurrency: 4, Requests: 40, Warmup: 5})
+	report := generator.Run(context.Background(),
+		LoadTest.Scenario{Name: "fast", Path: "/fast"},
+		LoadTest.Scenario{Name: "slow", Path: "/slow"},
+		LoadTest.Scenario{Name: "login", Method: http.MethodPost, Path: "/login", Body: []byte(`{}`), Expect: http.StatusUnauthorized},
+		LoadTest.Scenario{Name: "missing", Path: "/missing"},
+	)
+	require.Len(t, report.Scenarios, 4)
+	assert.Equal(t, 4, report.Concurrency)
+
+	// 预热请求不计入统计
+	fast := report.Scenario("fast")
+	assert.Equal(t, int64(45), atomic.LoadInt64(&calls))
+	assert.Equal(t, 40, fast.Requests)
+	assert.Zero(t, fast.Errors)
+	assert.Greater(t, fast.Throughput, 0.0)
+
+	slow := report.Scenario("slow")
+	assert.GreaterOrEqual(t, slow.P50, 2*time.Millisecond)
+	assert.LessOrEqual(t, slow.P50, slow.P95)
+	assert.LessOrEqual(t, slow.P95, slow.P99)
+	assert.LessOrEqual(t, slow.P99, slow.Max)
+
+	assert.Zero(t, report.Scenario("login").Errors)
+	missing := report.Scenario("missing")
+	assert.Equal(t, 40, missing.Errors)
+	assert.Equal(t, 1.0, missing.ErrorRate)
+	assert.Contains(t, missing.LastError, "返回状态码 404")
+}
+
+func TestGeneratorStopsWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int64
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&calls, 1) == 10 {
+			cancel()
+		}
+	})
+	report := LoadTest.NewGenerator(handler, LoadTest.Options{Concurrency: 1, Requests: 1000, Warmup: -1}).
+		Run(ctx, LoadTest.Scenario{Name: "canceled", Path: "/"})
+	assert.Equal(t, 10, report.Scenarios[0].Requests)
+}
+
+func TestPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 50*time.Millisecond, LoadTest.Percentile(latencies, 50))
+	assert.Equal(t, 95*time.Millisecond, LoadTest.Percentile(latencies, 95))
+	assert.Equal(t, 100*time.Millisecond, LoadTest.Percentile(latencies, 100))
+	assert.Equal(t, time.Millisecond, LoadTest.Percentile(latencies, 0))
+	assert.Equal(t, 3*time.Millisecond, LoadTest.Percentile([]time.Duration{time.Millisecond, 3 * time.Millisecond}, 95))
+	assert.Zero(t, LoadTest.Percentile(nil, 95))
+}
+
+func TestCompareWithBaseline(t *testing.T) {
+	baseline := &LoadTest.Report{Scenarios: []*LoadTest.ScenarioResult{
+		{Name: "login", P95: 20 * time.Millisecond},
+		{Name: "metrics", P95: 100 * time.Microsecond},
+		{Name: "alerts", P95: 10 * time.Millisecond},
+	}}
+	current := &LoadTest.Report{Scenarios: []*LoadTest.ScenarioResult{
+		{Name: "login", P95: 30 * time.Millisecond},                              // +50%，回归
+		{Name: "metrics", P95: 900 * time.Microsecond},                           // 比例超出但不到 MinDelta
+		{Name: "alerts", P95: 11 * time.Millisecond},                             // 在容忍范围内
+		{Name: "new", P95: time.Second, Requests: 10, Errors: 1, ErrorRate: 0.1}, // 无基线，只检查失败率
+	}}
+	regressions := current.Compare(baseline, LoadTest.GateOptions{Tolerance: 0.2, MinDelta: time.Millisecond})
+	require.Len(t, regressions, 2)
+	assert.Equal(t, "login", regressions[0].Scenario)
+	assert.Equal(t, "p95", regressions[0].Metric)
+	assert.Contains(t, regressions[0].String(), "P95 由 20ms 增加到 30ms (+50.0%)")
+	assert.Equal(t, "new", regressions[1].Scenario)
+	assert.Equal(t, "error_rate", regressions[1].Metric)
+	assert.Contains(t, regressions[1].String(), "请求失败率 10.00%")
+
+	assert.Len(t, current.Compare(baseline, LoadTest.GateOptions{Tolerance: 0.6, MaxErrorRate: 0.2}), 1)
+}
+
+func TestReportFileRoundTrip(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "perf", "baseline.json")
+	report := &LoadTest.Report{
+		GeneratedAt: time.Now().Truncate(time.Second),
+		Concurrency: 8,
+		Scenarios:   []*LoadTest.ScenarioResult{{Name: "login", Requests: 100, P95: 12 * time.Millisecond}},
+	}
+	require.NoError(t, report.WriteFile(filename))
+
+	loaded, err := LoadTest.LoadReport(filename)
+	require.NoError(t, err)
+	assert.True(t, report.GeneratedAt.Equal(loaded.GeneratedAt))
+	assert.Equal(t, 12*time.Millisecond, loaded.Scenario("login").P95)
+	assert.Nil(t, loaded.Scenario("alerts"))
+}
@@ -0,0 +1,136 @@
+package Logging
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Services"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// combinedPattern GoAccess 的 COMBINED 日志格式
+var combinedPattern = regexp.MustCompile(`^(\S+) - (\S+) \[([^\]]+)\] "((?:[^"\\]|\\.)*)" (\d{3}) (\d+|-) "((?:[^"\\]|\\.)*)" "((?:[^"\\]|\\.)*)"$`)
+
+func TestCombinedFormatter(t *testing.T) {
+	start := time.Date(2024, 10, 10, 13, 55, 36, 0, time.FixedZone("", -7*3600))
+	entry := Services.LogEntry{
+		Logger:    "access",
+		Timestamp: start.Add(time.Second),
+		Fields: map[string]interface{}{
+			"client_ip":     "192.0.2.10",
+			"username":      "alice",
+			"user_id":       uint(7),
+			"method":        "GET",
+			"uri":           "/api/v1/posts?page=2",
+			"protocol":      "HTTP/1.1",
+			"status_code":   200,
+			"response_size": 2326,
+			"referer":       "https://example.com/",
+			"user_agent":    `Mozilla/5.0 "quoted"`,
+			"start_time":    start.Format(time.RFC3339Nano),
+		},
+	}
+
+	data, err := (&Services.CombinedFormatter{}).Format(entry)
+	require.NoError(t, err)
+	assert.Equal(t, `192.0.2.10 - alice [10/Oct/2024:13:55:36 -0700] "GET /api/v1/posts?page=2 HTTP/1.1" 200 2326 "https://example.com/" "Mozilla/5.0 \"quoted\""`, string(data))
+	assert.Regexp(t, combinedPattern, string(data))
+
+	data, err = (&Services.CombinedFormatter{Common: true}).Format(entry)
+	require.NoError(t, err)
+	assert.Equal(t, `192.0.2.10 - alice [10/Oct/2024:13:55:36 -0700] "GET /api/v1/posts?page=2 HTTP/1.1" 200 2326`, string(data))
+
+	// 缺少的字段输出 -，没有 uri 时由 path 和 query 拼接，控制字符被转义
+	entry.Fields = map[string]interface{}{
+		"method":        "POST",
+		"path":          "/login\nforged",
+		"query":         "next=/",
+		"protocol":      "HTTP/2.0",
+		"status_code":   204,
+		"response_size": -1,
+	}
+	data, err = (&Services.CombinedFormatter{}).Format(entry)
+	require.NoError(t, err)
+	assert.Equal(t, `- - - [10/Oct/2024:13:55:37 -0700] "POST /login\nforged?next=/ HTTP/2.0" 204 - "-" "-"`, string(data))
+	assert.NotContains(t, string(data), "\n")
+}
+
+func TestHTTPLogFormatValidation(t *testing.T) {
+	config := queryConfig(t)
+	config.AccessLog.Format = Config.LogFormatCombined
+	config.RequestLog.Format = Config.LogFormatCommon
+	assert.NoError(t, config.Validate())
+
+	config.SQLLog.Format = Config.LogFormatCombined
+	assert.Error(t, config.Validate(), "只有请求日志和访问日志支持 combined 格式")
+}
+
+// TestAccessLogCombinedAlongsideJSON 访问日志使用 combined 格式，请求日志仍为JSON
+func TestAccessLogCombinedAlongsideJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager, config := newLogManager(t, func(config *Config.LogConfig) {
+		config.AccessLog.Format = Config.LogFormatCombined
+		config.RequestLog.FilterPaths = []string{"/health"}
+	})
+
+	router := gin.New()
+	router.Use(Middleware.NewRequestLogMiddleware(manager).RequestLog())
+	router.GET("/api/v1/posts", func(c *gin.Context) {
+		c.Set("username", "alice")
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	})
+	router.GET("/health", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/posts?page=2", nil)
+	req.RemoteAddr = "192.0.2.10:51234"
+	req.Header.Set("User-Agent", "GoAccess-Test/1.0")
+	req.Header.Set("Referer", "https://example.com/")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	waitForTotal(t, manager, "access", 2)
+	waitForTotal(t, manager, "request", 1)
+
+	lines := readLogLines(t, config, config.AccessLog.Path, "access")
+	require.Len(t, lines, 2, "访问日志记录所有请求，不受 REQUEST_LOG_FILTER_PATHS 影响")
+	match := combinedPattern.FindStringSubmatch(lines[0])
+	require.NotNil(t, match, lines[0])
+	assert.Equal(t, "192.0.2.10", match[1])
+	assert.Equal(t, "alice", match[2])
+	_, err := time.Parse("02/Jan/2006:15:04:05 -0700", match[3])
+	assert.NoError(t, err)
+	assert.Equal(t, "GET /api/v1/posts?page=2 HTTP/1.1", match[4])
+	assert.Equal(t, "200", match[5])
+	assert.Equal(t, "16", match[6], `{"success":true}`)
+	assert.Equal(t, "https://example.com/", match[7])
+	assert.Equal(t, "GoAccess-Test/1.0", match[8])
+	assert.Regexp(t, `"GET /health HTTP/1.1" 204 - `, lines[1])
+
+	requestLines := readLogLines(t, config, config.RequestLog.Path, "request")
+	require.Len(t, requestLines, 1)
+	var entry Services.LogEntry
+	require.NoError(t, json.Unmarshal([]byte(requestLines[0]), &entry), "请求日志仍为JSON")
+	assert.Equal(t, "/api/v1/posts", entry.Fields["path"])
+}
+
+// readLogLines 读取日志记录器当天的日志文件
+func readLogLines(t *testing.T, config *Config.LogConfig, dir, logger string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(config.BasePath, dir, logger+"-*.log"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	return strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+}
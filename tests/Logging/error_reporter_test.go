@@ -0,0 +1,280 @@
+package Logging
+
+import (
+	"bufio"
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Services"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reportServer 模拟上报服务，记录收到的请求
+type reportServer struct {
+	*httptest.Server
+	requests chan *http.Request
+	bodies   chan []byte
+}
+
+func newReportServer(t *testing.T) *reportServer {
+	server := &reportServer{requests: make(chan *http.Request, 10), bodies: make(chan []byte, 10)}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		server.requests <- r
+		server.bodies <- body
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// next 等待下一次上报请求
+func (s *reportServer) next(t *testing.T) (*http.Request, []byte) {
+	select {
+	case r := <-s.requests:
+		return r, <-s.bodies
+	case <-time.After(2 * time.Second):
+		t.Fatal("没有收到上报请求")
+		return nil, nil
+	}
+}
+
+func errorReportingConfig(configure func(*Config.ErrorReportingConfig)) func(*Config.LogConfig) {
+	return func(config *Config.LogConfig) {
+		config.ErrorReporting.SetDefaults()
+		config.ErrorReporting.Enabled = true
+		config.ErrorReporting.Release = "v1.2.3"
+		config.ErrorReporting.Environment = "staging"
+		configure(&config.ErrorReporting)
+	}
+}
+
+func TestErrorReportingSentryLogError(t *testing.T) {
+	server := newReportServer(t)
+	manager, _ := newLogManager(t, errorReportingConfig(func(config *Config.ErrorReportingConfig) {
+		config.Provider = "sentry"
+		config.DSN = strings.Replace(server.URL, "http://", "http://public-key@", 1) + "/42"
+	}))
+	require.NotNil(t, manager.ErrorReporter())
+
+	scope := manager.ErrorReporter().NewScope()
+	scope.SetRequest(httptest.NewRequest(http.MethodPost, "/api/v1/orders?token=abc", nil), "10.0.0.1")
+	ctx := Services.WithErrorScope(context.Background(), scope)
+	ctx = context.WithValue(ctx, "user_id", "42")
+	ctx = context.WithValue(ctx, "request_id", "req-1")
+	manager.LogBusiness(ctx, "orders", "create", "创建订单", nil)
+
+	manager.LogError(ctx, errors.New("charge failed for alice@example.com"), "支付失败", map[string]interface{}{
+		"order_id": 7,
+		"password": "secret",
+	})
+
+	r, body := server.next(t)
+	assert.Equal(t, "/api/42/envelope/", r.URL.Path)
+	assert.Contains(t, r.Header.Get("X-Sentry-Auth"), "sentry_key=public-key")
+
+	// envelope：信封头、事件项头、事件
+	lines := bufio.NewScanner(bytes.NewReader(body))
+	var items [][]byte
+	for lines.Scan() {
+		items = append(items, append([]byte(nil), lines.Bytes()...))
+	}
+	require.Len(t, items, 3)
+	var event map[string]interface{}
+	require.NoError(t, json.Unmarshal(items[2], &event))
+
+	assert.Equal(t, "v1.2.3", event["release"])
+	assert.Equal(t, "staging", event["environment"])
+	assert.Equal(t, "error", event["level"])
+	assert.Equal(t, map[string]interface{}{"id": "42"}, event["user"], "不发送个人信息时不包含客户端IP")
+	assert.Equal(t, "req-1", event["tags"].(map[string]interface{})["request_id"])
+
+	extra := event["extra"].(map[string]interface{})
+	assert.Equal(t, "***MASKED***", extra["password"])
+	assert.EqualValues(t, 7, extra["order_id"])
+
+	exception := event["exception"].(map[string]interface{})["values"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "*errors.errorString", exception["type"])
+	assert.NotContains(t, exception["value"], "alice@example.com")
+
+	request := event["request"].(map[string]interface{})
+	assert.Equal(t, "/api/v1/orders", request["url"])
+	assert.NotContains(t, request["query_string"], "abc")
+
+	breadcrumbs := event["breadcrumbs"].(map[string]interface{})["values"].([]interface{})
+	require.Len(t, breadcrumbs, 1)
+	assert.Equal(t, "business.orders", breadcrumbs[0].(map[string]interface{})["category"])
+	assert.Equal(t, "创建订单", breadcrumbs[0].(map[string]interface{})["message"])
+}
+
+func TestErrorReportingRecoveryPanicToBugsnag(t *testing.T) {
+	server := newReportServer(t)
+	manager, _ := newLogManager(t, errorReportingConfig(func(config *Config.ErrorReportingConfig) {
+		config.Provider = "bugsnag"
+		config.APIKey = "bugsnag-key"
+		config.Endpoint = server.URL
+	}))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	recovery := Middleware.NewRecoveryMiddleware(nil)
+	recovery.SetErrorReporter(manager.ErrorReporter())
+	router.Use(recovery.Handle())
+	router.GET("/api/v1/panic", func(c *gin.Context) {
+		c.Set("user_id", "7")
+		manager.LogAudit(c.Request.Context(), "delete", "post", 3, nil)
+		panic("nil map write")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/panic", nil)
+	req.Header.Set("Cookie", "session=abc")
+	req.Header.Set("Authorization", "Bearer abc")
+	req.Header.Set("X-Custom", "value")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	r, body := server.next(t)
+	assert.Equal(t, "bugsnag-key", r.Header.Get("Bugsnag-Api-Key"))
+	var payload struct {
+		Events []struct {
+			Exceptions []struct {
+				ErrorClass string `json:"errorClass"`
+				Message    string `json:"message"`
+				Stacktrace []struct {
+					Method    string `json:"method"`
+					InProject bool   `json:"inProject"`
+				} `json:"stacktrace"`
+			} `json:"exceptions"`
+			Unhandled      bool              `json:"unhandled"`
+			SeverityReason map[string]string `json:"severityReason"`
+			App            map[string]string `json:"app"`
+			User           map[string]string `json:"user"`
+			Context        string            `json:"context"`
+			Request        struct {
+				ClientIP string            `json:"clientIp"`
+				Headers  map[string]string `json:"headers"`
+			} `json:"request"`
+			Breadcrumbs []struct {
+				MetaData map[string]interface{} `json:"metaData"`
+			} `json:"breadcrumbs"`
+		} `json:"events"`
+	}
+	require.NoError(t, json.Unmarshal(body, &payload))
+	require.Len(t, payload.Events, 1)
+	event := payload.Events[0]
+
+	assert.True(t, event.Unhandled)
+	assert.Equal(t, "unhandledPanic", event.SeverityReason["type"])
+	assert.Equal(t, map[string]string{"version": "v1.2.3", "releaseStage": "staging"}, event.App)
+	assert.Equal(t, "7", event.User["id"])
+	assert.Equal(t, "GET /api/v1/panic", event.Context)
+
+	exception := event.Exceptions[0]
+	assert.Equal(t, "nil map write", exception.Message)
+	require.NotEmpty(t, exception.Stacktrace)
+	assert.Contains(t, exception.Stacktrace[0].Method, "TestErrorReportingRecoveryPanicToBugsnag", "堆栈从发生panic的位置开始")
+	assert.True(t, exception.Stacktrace[0].InProject)
+
+	assert.Empty(t, event.Request.ClientIP)
+	assert.NotContains(t, event.Request.Headers, "Cookie")
+	assert.NotContains(t, event.Request.Headers, "Authorization")
+	assert.Equal(t, "value", event.Request.Headers["X-Custom"])
+
+	require.Len(t, event.Breadcrumbs, 1)
+	assert.Equal(t, "audit", event.Breadcrumbs[0].MetaData["category"])
+}
+
+// memoryTransport 内存事件发送
+type memoryTransport struct {
+	mu     sync.Mutex
+	events []*Services.ErrorEvent
+}
+
+func (m *memoryTransport) Name() string { return "memory" }
+
+func (m *memoryTransport) Send(ctx context.Context, event *Services.ErrorEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *memoryTransport) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.events)
+}
+
+func TestErrorReporterSamplingAndRateLimit(t *testing.T) {
+	config := &Config.ErrorReportingConfig{}
+	config.SetDefaults()
+	config.Enabled = true
+	config.Provider = "bugsnag"
+	config.APIKey = "key"
+	config.SampleRate = 0.5
+	config.MaxEventsPerMinute = 2
+
+	reporter, err := Services.NewErrorReporter(config, Config.LogMaskingConfig{}, "production")
+	require.NoError(t, err)
+	transport := &memoryTransport{}
+	reporter.SetTransport(transport)
+	reporter.SetRandom(func() float64 { return 0.9 })
+	reporter.Start()
+
+	ctx := context.Background()
+	assert.False(t, reporter.CaptureError(ctx, errors.New("sampled"), "sampled", nil), "超出采样比例的错误不上报")
+	assert.True(t, reporter.CapturePanic(ctx, "boom", nil), "panic 使用单独的采样比例")
+
+	reporter.SetRandom(func() float64 { return 0.1 })
+	assert.True(t, reporter.CaptureError(ctx, errors.New("first"), "first", nil))
+	assert.False(t, reporter.CaptureError(ctx, errors.New("limited"), "limited", nil), "超过每分钟上限")
+
+	reporter.Close(time.Second)
+	assert.Equal(t, 2, transport.count())
+	assert.Equal(t, "production", transport.events[0].Environment)
+	stats := reporter.Stats()
+	assert.Equal(t, int64(2), stats.Sent)
+	assert.Equal(t, int64(1), stats.Sampled)
+	assert.Equal(t, int64(1), stats.RateLimited)
+}
+
+func TestErrorReportingConfigValidate(t *testing.T) {
+	endpoint, key, err := Config.ParseSentryDSN("https://abc@sentry.example.com/prefix/17")
+	require.NoError(t, err)
+	assert.Equal(t, "https://sentry.example.com/prefix/api/17/envelope/", endpoint)
+	assert.Equal(t, "abc", key)
+
+	config := &Config.ErrorReportingConfig{}
+	config.SetDefaults()
+	assert.NoError(t, config.Validate(), "未启用时不检查")
+
+	config.Enabled = true
+	config.DSN = "https://abc@o1.ingest.sentry.io/1"
+	assert.NoError(t, config.Validate())
+
+	for name, configure := range map[string]func(*Config.ErrorReportingConfig){
+		"dsn":         func(c *Config.ErrorReportingConfig) { c.DSN = "https://sentry.example.com/1" },
+		"provider":    func(c *Config.ErrorReportingConfig) { c.Provider = "rollbar" },
+		"api_key":     func(c *Config.ErrorReportingConfig) { c.Provider = "bugsnag" },
+		"sample_rate": func(c *Config.ErrorReportingConfig) { c.SampleRate = 1.5 },
+		"queue_size":  func(c *Config.ErrorReportingConfig) { c.QueueSize = 0 },
+	} {
+		invalid := *config
+		configure(&invalid)
+		assert.Error(t, invalid.Validate(), name)
+	}
+}
@@ -0,0 +1,146 @@
+package Logging
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Utils"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type gormLoggerWidget struct {
+	ID   uint
+	Name string
+}
+
+// setupGormLogger 使用日志管理器的GORM日志适配器打开测试数据库
+func setupGormLogger(t *testing.T, configure func(*Config.LogConfig)) (*gorm.DB, *Services.LogManagerService, *Config.LogConfig) {
+	manager, config := newLogManager(t, func(c *Config.LogConfig) {
+		c.SQLLog.Enabled = true
+		c.SQLLog.IncludeParams = true
+		c.SQLLog.SlowThreshold = time.Minute
+		if configure != nil {
+			configure(c)
+		}
+	})
+	gormLogger := manager.GormLogger().(*Services.GormLogger)
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "gorm.db")), &gorm.Config{Logger: gormLogger})
+	require.NoError(t, err)
+	require.NoError(t, gormLogger.Install(db))
+	require.NoError(t, db.AutoMigrate(&gormLoggerWidget{}))
+	return db, manager, config
+}
+
+// searchSQL 刷新队列后查询SQL日志
+func searchSQL(t *testing.T, manager *Services.LogManagerService, config *Config.LogConfig, text string) []Services.LogEntry {
+	require.NoError(t, manager.Flush(context.Background()))
+	result, err := Services.NewLogQueryService(config).Search(context.Background(), Services.LogQuery{Loggers: []string{"sql"}, Text: text, PageSize: 100})
+	require.NoError(t, err)
+	return result.Entries
+}
+
+func TestGormLoggerRoutesStatementsThroughLogSQL(t *testing.T) {
+	db, manager, config := setupGormLogger(t, nil)
+	ctx := Utils.WithRequestIDs(context.Background(), "req-gorm-1", "corr-gorm-1")
+
+	require.NoError(t, db.WithContext(ctx).Create(&gormLoggerWidget{Name: "gear"}).Error)
+	var widgets []gormLoggerWidget
+	require.NoError(t, db.WithContext(ctx).Where("name = ?", "gear").Find(&widgets).Error)
+	require.Len(t, widgets, 1)
+	// 记录不存在不视为错误
+	var missing gormLoggerWidget
+	assert.ErrorIs(t, db.WithContext(ctx).First(&missing, 999).Error, gorm.ErrRecordNotFound)
+	assert.Error(t, db.WithContext(ctx).Exec("SELECT * FROM missing_table").Error)
+
+	entries := searchSQL(t, manager, config, "req-gorm-1")
+	require.NotEmpty(t, entries)
+	var inserted, failed bool
+	for _, entry := range entries {
+		assert.Equal(t, "corr-gorm-1", entry.Fields["correlation_id"])
+		assert.Equal(t, "gorm", entry.Fields["source"])
+		switch entry.Fields["operation"] {
+		case "insert":
+			inserted = true
+			assert.Equal(t, "gormLoggerWidget", entry.Fields["model"])
+			assert.Equal(t, "gorm_logger_widgets", entry.Fields["table"])
+			assert.Contains(t, entry.Fields["sql"], `"gear"`)
+		case "select":
+			if entry.Fields["error"] != nil {
+				failed = true
+				assert.Equal(t, Config.LogLevelError, entry.Level)
+				assert.Equal(t, "missing_table", entry.Fields["model"])
+			}
+		}
+	}
+	assert.True(t, inserted)
+	assert.True(t, failed)
+
+	// 迁移语句同样写入SQL日志
+	assert.NotEmpty(t, searchSQL(t, manager, config, "CREATE TABLE"))
+
+	stats := make(map[string]Services.SQLModelStats)
+	for _, stat := range manager.SQLStats() {
+		stats[stat.Model] = stat
+	}
+	widget := stats["gormLoggerWidget"]
+	assert.Equal(t, int64(1), widget.Inserts)
+	assert.GreaterOrEqual(t, widget.Selects, int64(2))
+	assert.Zero(t, widget.Errors)
+	assert.Zero(t, widget.SlowQueries)
+	assert.Equal(t, int64(1), stats["missing_table"].Errors)
+	assert.Contains(t, stats["missing_table"].LastStatement, "missing_table")
+}
+
+func TestGormLoggerFlagsSlowQueriesAndHidesParams(t *testing.T) {
+	db, manager, config := setupGormLogger(t, func(c *Config.LogConfig) {
+		c.SQLLog.SlowThreshold = time.Nanosecond
+		c.SQLLog.IncludeParams = false
+	})
+
+	require.NoError(t, db.Create(&gormLoggerWidget{Name: "secret-value"}).Error)
+
+	entries := searchSQL(t, manager, config, "INSERT INTO")
+	require.NotEmpty(t, entries)
+	for _, entry := range entries {
+		assert.Equal(t, Config.LogLevelWarning, entry.Level)
+		assert.Equal(t, true, entry.Fields["slow_query"])
+		assert.NotContains(t, entry.Fields["sql"], "secret-value")
+		assert.Contains(t, entry.Fields["sql"], "?")
+	}
+
+	var widget Services.SQLModelStats
+	for _, stat := range manager.SQLStats() {
+		if stat.Model == "gormLoggerWidget" {
+			widget = stat
+		}
+	}
+	assert.Equal(t, int64(1), widget.Inserts)
+	assert.GreaterOrEqual(t, widget.SlowQueries, int64(1))
+	assert.NotEmpty(t, widget.LastStatement)
+}
+
+func TestGormLoggerStatsWithoutSQLLog(t *testing.T) {
+	db, manager, _ := setupGormLogger(t, func(c *Config.LogConfig) {
+		c.SQLLog.Enabled = false
+	})
+
+	require.NoError(t, db.Exec("INSERT INTO gorm_logger_widgets (name) VALUES (?)", "raw").Error)
+	assert.Zero(t, manager.GetStats().LogsByLogger["sql"])
+
+	// SQL日志未启用时仍然统计，原生SQL按表名归类
+	var found bool
+	for _, stat := range manager.SQLStats() {
+		if stat.Model == "gorm_logger_widgets" {
+			found = true
+			assert.Equal(t, int64(1), stat.Inserts)
+		}
+	}
+	assert.True(t, found)
+}
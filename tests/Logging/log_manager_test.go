@@ -0,0 +1,104 @@
+package Logging
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Services"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLogManager(t *testing.T, configure func(*Config.LogConfig)) (*Services.LogManagerService, *Config.LogConfig) {
+	config := queryConfig(t)
+	if configure != nil {
+		configure(config)
+	}
+	manager := Services.NewLogManagerService(config)
+	t.Cleanup(func() { manager.Close() })
+	return manager, config
+}
+
+// waitForTotal 等待异步队列处理完成
+func waitForTotal(t *testing.T, manager *Services.LogManagerService, logger string, total int64) {
+	require.Eventually(t, func() bool {
+		return manager.GetStats().LogsByLogger[logger] >= total
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestLogSampling(t *testing.T) {
+	manager, _ := newLogManager(t, func(config *Config.LogConfig) {
+		config.Sampling.Enabled = true
+		config.Sampling.Rate = 1
+		config.Sampling.LoggerRates = []string{"business=5"}
+	})
+
+	for i := 0; i < 20; i++ {
+		manager.Info("business", "sampled", nil)
+	}
+	// 警告及以上级别不参与采样
+	for i := 0; i < 3; i++ {
+		manager.Warning("business", "kept", nil)
+	}
+	waitForTotal(t, manager, "business", 7)
+
+	stats := manager.GetStats()
+	assert.Equal(t, int64(16), stats.Sampled)
+	assert.Equal(t, int64(16), stats.SampledByLogger["business"])
+	assert.Equal(t, int64(7), stats.LogsByLogger["business"])
+}
+
+func TestLogDropPolicy(t *testing.T) {
+	manager, _ := newLogManager(t, func(config *Config.LogConfig) {
+		config.QueueSize = 1
+		config.OverflowPolicy = "drop"
+	})
+
+	for i := 0; i < 2000; i++ {
+		manager.Info("business", "flood", nil)
+	}
+
+	stats := manager.GetStats()
+	assert.Greater(t, stats.Dropped, int64(0))
+	assert.Equal(t, stats.Dropped, stats.DroppedByLogger["business"])
+}
+
+func TestRuntimeLogLevel(t *testing.T) {
+	manager, config := newLogManager(t, func(config *Config.LogConfig) {
+		config.SecurityLog.Level = Config.LogLevelWarning
+	})
+	query := Services.NewLogQueryService(config)
+	ctx := context.Background()
+
+	// 统计在写入文件之后更新，等待统计即可保证文件已写入
+	search := func(message string) int {
+		result, err := query.Search(ctx, Services.LogQuery{Loggers: []string{"security"}, Text: message})
+		require.NoError(t, err)
+		return result.Total
+	}
+
+	// 按严重程度比较：error 高于 warning，应当写入
+	manager.Error("security", "error before", nil)
+	manager.Info("security", "info before", nil)
+	waitForTotal(t, manager, "security", 2)
+	assert.Equal(t, 1, search("error before"))
+	assert.Equal(t, 0, search("info before"))
+
+	require.NoError(t, manager.SetLoggerLevel("security", Config.LogLevelDebug))
+	manager.Info("security", "info after", nil)
+	waitForTotal(t, manager, "security", 3)
+	assert.Equal(t, 1, search("info after"))
+
+	assert.Error(t, manager.SetLoggerLevel("security", "verbose"))
+	assert.Error(t, manager.SetLoggerLevel("unknown", Config.LogLevelInfo))
+
+	require.NoError(t, manager.SetSampleRate("security", 10))
+	for _, info := range manager.GetLoggerLevels() {
+		if info.Logger == "security" {
+			assert.Equal(t, Config.LogLevelDebug, info.Level)
+			assert.Equal(t, 10, info.SampleRate)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package Logging
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func queryConfig(t *testing.T) *Config.LogConfig {
+	config := &Config.LogConfig{}
+	config.SetDefaults()
+	config.BasePath = t.TempDir()
+	return config
+}
+
+// writeLogFile 按 LogManagerService 的文件布局写入日志
+func writeLogFile(t *testing.T, config *Config.LogConfig, dir, logger, date string, entries ...Services.LogEntry) string {
+	path := filepath.Join(config.BasePath, dir, fmt.Sprintf("%s-%s.log", logger, date))
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	defer file.Close()
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		require.NoError(t, err)
+		file.Write(append(data, '\n'))
+	}
+	return path
+}
+
+func TestLogQuerySearch(t *testing.T) {
+	config := queryConfig(t)
+	day1 := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	day2 := day1.Add(24 * time.Hour)
+
+	writeLogFile(t, config, config.ErrorLog.Path, "error", "2024-05-01",
+		Services.LogEntry{Logger: "error", Level: Config.LogLevelError, Message: "db timeout", Timestamp: day1, TraceID: "t-1"},
+		Services.LogEntry{Logger: "error", Level: Config.LogLevelWarning, Message: "slow call", Timestamp: day1.Add(time.Minute)},
+	)
+	writeLogFile(t, config, config.ErrorLog.Path, "error", "2024-05-02",
+		Services.LogEntry{Logger: "error", Level: Config.LogLevelError, Message: "DB Timeout again", Timestamp: day2},
+	)
+	writeLogFile(t, config, config.SecurityLog.Path, "security", "2024-05-02",
+		Services.LogEntry{Logger: "security", Level: Config.LogLevelFatal, Message: "brute force", Timestamp: day2.Add(time.Hour), TraceID: "t-1"},
+	)
+	service := Services.NewLogQueryService(config)
+	ctx := context.Background()
+
+	t.Run("跨日志记录器按时间倒序", func(t *testing.T) {
+		result, err := service.Search(ctx, Services.LogQuery{})
+		require.NoError(t, err)
+		assert.Equal(t, 4, result.Total)
+		require.Len(t, result.Entries, 4)
+		assert.Equal(t, "brute force", result.Entries[0].Message)
+		assert.Equal(t, "db timeout", result.Entries[3].Message)
+	})
+
+	t.Run("关键词不区分大小写", func(t *testing.T) {
+		result, err := service.Search(ctx, Services.LogQuery{Loggers: []string{"error"}, Text: "db timeout"})
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Total)
+	})
+
+	t.Run("级别和链路追踪ID", func(t *testing.T) {
+		result, err := service.Search(ctx, Services.LogQuery{MinLevel: Config.LogLevelError, TraceID: "t-1"})
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Total)
+
+		result, err = service.Search(ctx, Services.LogQuery{Levels: []Config.LogLevel{Config.LogLevelWarning}})
+		require.NoError(t, err)
+		require.Equal(t, 1, result.Total)
+		assert.Equal(t, "slow call", result.Entries[0].Message)
+	})
+
+	t.Run("时间范围和分页", func(t *testing.T) {
+		from := day2
+		result, err := service.Search(ctx, Services.LogQuery{From: &from, Page: 2, PageSize: 1})
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Total)
+		require.Len(t, result.Entries, 1)
+		assert.Equal(t, "DB Timeout again", result.Entries[0].Message)
+	})
+
+	t.Run("文件列表", func(t *testing.T) {
+		files, err := service.Files("error")
+		require.NoError(t, err)
+		require.Len(t, files, 2)
+		assert.Equal(t, "2024-05-02", files[0].Date)
+
+		_, err = service.Files("unknown")
+		assert.Error(t, err)
+	})
+}
+
+func TestLogQueryTail(t *testing.T) {
+	config := queryConfig(t)
+	today := time.Now().Format("2006-01-02")
+	writeLogFile(t, config, config.ErrorLog.Path, "error", today,
+		Services.LogEntry{Logger: "error", Level: Config.LogLevelError, Message: "before tail", Timestamp: time.Now()},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	service := Services.NewLogQueryService(config)
+	entries, err := service.Tail(ctx, "error", Services.LogQuery{MinLevel: Config.LogLevelError})
+	require.NoError(t, err)
+
+	// 等待跟踪协程记录初始位置
+	time.Sleep(100 * time.Millisecond)
+	writeLogFile(t, config, config.ErrorLog.Path, "error", today,
+		Services.LogEntry{Logger: "error", Level: Config.LogLevelInfo, Message: "filtered", Timestamp: time.Now()},
+		Services.LogEntry{Logger: "error", Level: Config.LogLevelError, Message: "after tail", Timestamp: time.Now()},
+	)
+
+	select {
+	case entry := <-entries:
+		assert.Equal(t, "after tail", entry.Message)
+	case <-time.After(3 * time.Second):
+		t.Fatal("未收到新增日志")
+	}
+
+	cancel()
+	for range entries {
+	}
+	assert.False(t, Services.IsTailable("request"))
+}
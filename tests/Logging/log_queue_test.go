@@ -0,0 +1,129 @@
+package Logging
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Services"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flood 在队列容量为1时写入大量日志，确保触发队列溢出
+func flood(manager *Services.LogManagerService, logger string, level Config.LogLevel, count int) {
+	for i := 0; i < count; i++ {
+		manager.Log(logger, level, "flood", nil)
+	}
+}
+
+func TestLogOverflowPolicyPerLogger(t *testing.T) {
+	manager, _ := newLogManager(t, func(config *Config.LogConfig) {
+		config.QueueSize = 1
+		config.OverflowPolicy = Config.LogOverflowSync
+		config.LoggerOverflowPolicies = []string{"business=drop", "access=drop_oldest"}
+	})
+
+	flood(manager, "business", Config.LogLevelInfo, 2000)
+	flood(manager, "access", Config.LogLevelInfo, 2000)
+	flood(manager, "request", Config.LogLevelInfo, 2000)
+	// 默认的 sync 策略不丢日志
+	waitForTotal(t, manager, "request", 2000)
+
+	stats := manager.GetStats()
+	assert.Greater(t, stats.Overflows, int64(0))
+	assert.Greater(t, stats.DroppedByPolicy[Config.LogOverflowDrop], int64(0))
+	assert.Greater(t, stats.DroppedByPolicy[Config.LogOverflowDropOldest], int64(0))
+	assert.Zero(t, stats.DroppedByLogger["request"])
+	assert.Greater(t, stats.SyncWrites, int64(0))
+	assert.Equal(t, stats.Dropped, stats.DroppedByLogger["business"]+stats.DroppedByLogger["access"])
+
+	policies := make(map[string]string)
+	for _, info := range manager.GetLoggerLevels() {
+		policies[info.Logger] = info.OverflowPolicy
+	}
+	assert.Equal(t, Config.LogOverflowDrop, policies["business"])
+	assert.Equal(t, Config.LogOverflowDropOldest, policies["access"])
+	assert.Equal(t, Config.LogOverflowSync, policies["request"])
+}
+
+func TestLogOverflowKeepsErrors(t *testing.T) {
+	manager, _ := newLogManager(t, func(config *Config.LogConfig) {
+		config.QueueSize = 1
+		config.OverflowPolicy = Config.LogOverflowDrop
+	})
+
+	// 错误级别日志不会被丢弃，队列满时改为同步写入
+	flood(manager, "business", Config.LogLevelError, 500)
+	waitForTotal(t, manager, "business", 500)
+	assert.Zero(t, manager.GetStats().Dropped)
+}
+
+func TestLogOverflowBlockAndSample(t *testing.T) {
+	blocking, _ := newLogManager(t, func(config *Config.LogConfig) {
+		config.QueueSize = 1
+		config.OverflowPolicy = Config.LogOverflowBlock
+		config.OverflowBlockTimeout = 5 * time.Second
+	})
+	flood(blocking, "business", Config.LogLevelInfo, 1000)
+	waitForTotal(t, blocking, "business", 1000)
+	stats := blocking.GetStats()
+	assert.Greater(t, stats.Blocked, int64(0))
+	assert.Zero(t, stats.Dropped)
+	assert.Zero(t, stats.SyncWrites)
+
+	sampling, _ := newLogManager(t, func(config *Config.LogConfig) {
+		config.QueueSize = 1
+		config.OverflowPolicy = Config.LogOverflowSample
+		config.OverflowSampleRate = 10
+	})
+	flood(sampling, "business", Config.LogLevelInfo, 2000)
+	stats = sampling.GetStats()
+	require.Greater(t, stats.Overflows, int64(0))
+	// 每10次溢出保留1条
+	assert.Equal(t, (stats.Overflows+9)/10, stats.Blocked)
+	assert.Equal(t, stats.Dropped, stats.DroppedByPolicy[Config.LogOverflowSample])
+	assert.GreaterOrEqual(t, stats.Dropped, stats.Overflows-stats.Blocked)
+}
+
+func TestLogQueueStatsAndCollector(t *testing.T) {
+	manager, _ := newLogManager(t, func(config *Config.LogConfig) {
+		config.QueueSize = 64
+		config.Workers = 3
+		config.BatchSize = 16
+	})
+
+	flood(manager, "business", Config.LogLevelInfo, 500)
+	waitForTotal(t, manager, "business", 500)
+
+	stats := manager.GetStats()
+	assert.Equal(t, 64, stats.QueueCapacity)
+	assert.Equal(t, 3, stats.Workers)
+	assert.Greater(t, stats.Batches, int64(0))
+	assert.LessOrEqual(t, stats.Batches, int64(500))
+
+	values, err := manager.Collector().Collect(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 64.0, values["log_queue_capacity"])
+	assert.Equal(t, 3.0, values["log_workers"])
+	assert.Contains(t, values, "log_queue_depth")
+	assert.Contains(t, values, "log_dropped_total")
+	assert.Equal(t, float64(stats.SyncWrites), values["log_sync_writes_total"])
+}
+
+func TestLogOverflowConfigValidate(t *testing.T) {
+	config := queryConfig(t)
+	config.LoggerOverflowPolicies = []string{"business=drop_oldest", "sql=sample"}
+	policies, err := config.ParseLoggerOverflowPolicies()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"business": "drop_oldest", "sql": "sample"}, policies)
+	assert.NoError(t, config.Validate())
+
+	config.LoggerOverflowPolicies = []string{"business=discard"}
+	assert.Error(t, config.Validate())
+
+	config.LoggerOverflowPolicies = nil
+	config.OverflowPolicy = "discard"
+	assert.Error(t, config.Validate())
+}
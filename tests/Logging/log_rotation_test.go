@@ -0,0 +1,195 @@
+package Logging
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Services"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// agedLogFile 写入日志文件并把修改时间设为 age 之前
+func agedLogFile(t *testing.T, config *Config.LogConfig, dir, logger, date string, age time.Duration) string {
+	path := writeLogFile(t, config, dir, logger, date, Services.LogEntry{
+		Logger: logger, Level: Config.LogLevelInfo, Message: "rotated " + date, Timestamp: time.Now().Add(-age),
+	})
+	modTime := time.Now().Add(-age)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+	return path
+}
+
+// gunzip 读取压缩文件内容
+func gunzip(t *testing.T, data []byte) string {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	content, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return string(content)
+}
+
+type failingArchiveStore struct{}
+
+func (failingArchiveStore) Put(ctx context.Context, key string, data []byte) error {
+	return errors.New("store unavailable")
+}
+
+func (failingArchiveStore) Get(ctx context.Context, key string) ([]byte, error) {
+	return nil, Services.ErrArchiveObjectNotFound
+}
+
+func TestLogRetentionCompressesAndDeletes(t *testing.T) {
+	config := queryConfig(t)
+	config.Rotation.MaxAge = 10 * 24 * time.Hour
+	config.Rotation.MaxBackups = 3
+	dir := filepath.Join(config.BasePath, config.BusinessLog.Path)
+
+	expired := agedLogFile(t, config, config.BusinessLog.Path, "business", "2026-09-01", 40*24*time.Hour)
+	for i, date := range []string{"2026-10-10", "2026-10-11", "2026-10-12", "2026-10-13"} {
+		agedLogFile(t, config, config.BusinessLog.Path, "business", date, time.Duration(5-i)*24*time.Hour)
+	}
+	// 审计日志按 AUDIT_LOG_RETENTION 保留，不受文件数量限制
+	for _, date := range []string{"2026-08-01", "2026-08-02", "2026-08-03", "2026-08-04"} {
+		agedLogFile(t, config, config.AuditLog.Path, "audit", date, 60*24*time.Hour)
+	}
+
+	manager, _ := newLogManager(t, func(c *Config.LogConfig) { *c = *config })
+	manager.MaintainLogFiles(context.Background())
+
+	assert.NoFileExists(t, expired)
+	assert.NoFileExists(t, filepath.Join(dir, "business-2026-10-10.log.gz"), "超过文件数量上限的最旧文件被删除")
+	for _, date := range []string{"2026-10-11", "2026-10-12", "2026-10-13"} {
+		assert.NoFileExists(t, filepath.Join(dir, "business-"+date+".log"))
+		assert.FileExists(t, filepath.Join(dir, "business-"+date+".log.gz"))
+	}
+	auditFiles, err := filepath.Glob(filepath.Join(config.BasePath, config.AuditLog.Path, "audit-2026-08-*.log.gz"))
+	require.NoError(t, err)
+	assert.Len(t, auditFiles, 4)
+
+	// 压缩后的文件仍可以搜索
+	result, err := Services.NewLogQueryService(config).Search(context.Background(), Services.LogQuery{Loggers: []string{"business"}, Text: "rotated 2026-10-12"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Total)
+}
+
+func TestLogRetentionByTotalSize(t *testing.T) {
+	config := queryConfig(t)
+	config.Rotation.Compress = false
+	config.Rotation.MaxBackups = 0
+	config.Rotation.MaxTotalSize = 1
+	dir := filepath.Join(config.BasePath, config.BusinessLog.Path)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+
+	chunk := bytes.Repeat([]byte("x"), 400*1024)
+	for i, date := range []string{"2026-10-01", "2026-10-02", "2026-10-03", "2026-10-04"} {
+		path := filepath.Join(dir, "business-"+date+".log")
+		require.NoError(t, os.WriteFile(path, chunk, 0644))
+		modTime := time.Now().Add(-time.Duration(4-i) * time.Hour)
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+
+	manager, _ := newLogManager(t, func(c *Config.LogConfig) { *c = *config })
+	manager.MaintainLogFiles(context.Background())
+
+	assert.NoFileExists(t, filepath.Join(dir, "business-2026-10-01.log"))
+	assert.NoFileExists(t, filepath.Join(dir, "business-2026-10-02.log"))
+	assert.FileExists(t, filepath.Join(dir, "business-2026-10-03.log"))
+	assert.FileExists(t, filepath.Join(dir, "business-2026-10-04.log"))
+}
+
+func TestLogArchivalUploadsBeforeRemovingOriginal(t *testing.T) {
+	config := queryConfig(t)
+	config.Rotation.Archive = true
+	config.Rotation.ArchivePrefix = "logs/"
+	manager, _ := newLogManager(t, func(c *Config.LogConfig) { *c = *config })
+	path := agedLogFile(t, config, config.BusinessLog.Path, "business", "2026-10-01", 24*time.Hour)
+
+	// 未设置归档存储时不压缩
+	manager.MaintainLogFiles(context.Background())
+	assert.FileExists(t, path)
+
+	// 上传失败时保留原文件，下次重试
+	manager.SetArchiveStore(failingArchiveStore{})
+	results := manager.MaintainLogFiles(context.Background())
+	assert.FileExists(t, path)
+	assert.NoFileExists(t, path+".gz")
+	var failed bool
+	for _, result := range results {
+		failed = failed || (result.Logger == "business" && len(result.Errors) > 0)
+	}
+	assert.True(t, failed)
+
+	store := Services.NewLocalArchiveStore(t.TempDir())
+	manager.SetArchiveStore(store)
+	results = manager.MaintainLogFiles(context.Background())
+	assert.NoFileExists(t, path)
+	assert.FileExists(t, path+".gz")
+	for _, result := range results {
+		if result.Logger == "business" {
+			assert.Equal(t, []string{"logs/business/business-2026-10-01.log.gz"}, result.Archived)
+		}
+	}
+	data, err := store.Get(context.Background(), "logs/business/business-2026-10-01.log.gz")
+	require.NoError(t, err)
+	assert.Contains(t, gunzip(t, data), "rotated 2026-10-01")
+}
+
+func TestLogRotateAndFlush(t *testing.T) {
+	manager, config := newLogManager(t, nil)
+	dir := filepath.Join(config.BasePath, config.BusinessLog.Path)
+	active := filepath.Join(dir, "business-"+time.Now().Format("2006-01-02")+".log")
+
+	for i := 0; i < 20; i++ {
+		manager.Info("business", "before rotate", nil)
+	}
+	require.NoError(t, manager.Flush(context.Background()))
+	data, err := os.ReadFile(active)
+	require.NoError(t, err)
+	assert.Equal(t, 20, bytes.Count(data, []byte("before rotate")))
+
+	results, err := manager.Rotate(context.Background(), []string{"business"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.NotEmpty(t, results[0].Rotated)
+	assert.Empty(t, results[0].Errors)
+	assert.Equal(t, []string{filepath.Base(results[0].Rotated) + ".gz"}, results[0].Compressed)
+	assert.NoFileExists(t, active)
+
+	manager.Info("business", "after rotate", nil)
+	require.NoError(t, manager.Flush(context.Background()))
+	data, err = os.ReadFile(active)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "before rotate")
+	assert.Contains(t, string(data), "after rotate")
+
+	files, err := Services.NewLogQueryService(config).Files("business")
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	for _, file := range files {
+		assert.Equal(t, time.Now().Format("2006-01-02"), file.Date)
+	}
+
+	_, err = manager.Rotate(context.Background(), []string{"missing"})
+	assert.Error(t, err)
+}
+
+func TestLogRotationConfigValidate(t *testing.T) {
+	config := queryConfig(t)
+	config.Rotation.Archive = true
+	config.Rotation.Compress = false
+	assert.Error(t, config.Validate())
+
+	config.Rotation.Compress = true
+	assert.NoError(t, config.Validate())
+
+	config.Rotation.MaxTotalSize = -1
+	assert.Error(t, config.Validate())
+}
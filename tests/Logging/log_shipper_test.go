@@ -0,0 +1,246 @@
+package Logging
+
+import (
+	"bufio"
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Services"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func shippingConfig() *Config.LogShippingConfig {
+	config := &Config.LogShippingConfig{}
+	config.SetDefaults()
+	config.Enabled = true
+	config.BatchSize = 2
+	config.FlushInterval = 20 * time.Millisecond
+	config.MaxRetries = 0
+	config.RetryBackoff = time.Millisecond
+	return config
+}
+
+func entry(logger string, level Config.LogLevel, message string) Services.LogEntry {
+	return Services.LogEntry{Logger: logger, Level: level, Message: message, Timestamp: time.Now()}
+}
+
+// memoryOutput 内存输出，可以切换为失败模式
+type memoryOutput struct {
+	mu      sync.Mutex
+	failing bool
+	entries []Services.LogEntry
+}
+
+func (o *memoryOutput) Name() string { return "memory" }
+
+func (o *memoryOutput) Send(ctx context.Context, entries []Services.LogEntry) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.failing {
+		return errors.New("output unavailable")
+	}
+	o.entries = append(o.entries, entries...)
+	return nil
+}
+
+func (o *memoryOutput) Close() error { return nil }
+
+func (o *memoryOutput) setFailing(failing bool) {
+	o.mu.Lock()
+	o.failing = failing
+	o.mu.Unlock()
+}
+
+func (o *memoryOutput) messages() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var messages []string
+	for _, e := range o.entries {
+		messages = append(messages, e.Message)
+	}
+	return messages
+}
+
+func TestLogShipperFilterAndBatch(t *testing.T) {
+	shipper := Services.NewLogShipper(shippingConfig(), t.TempDir())
+	output := &memoryOutput{}
+	shipper.AddOutput(output, []string{"error", "audit"}, Config.LogLevelWarning)
+	shipper.Start()
+
+	shipper.Ship(entry("error", Config.LogLevelError, "kept"))
+	shipper.Ship(entry("error", Config.LogLevelInfo, "below level"))
+	shipper.Ship(entry("request", Config.LogLevelError, "other logger"))
+	shipper.Ship(entry("audit", Config.LogLevelFatal, "kept too"))
+	require.NoError(t, shipper.Close())
+
+	assert.Equal(t, []string{"kept", "kept too"}, output.messages())
+	stats := shipper.Stats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, int64(2), stats[0].Shipped)
+}
+
+func TestLogShipperBuffersAndResumes(t *testing.T) {
+	dir := t.TempDir()
+	config := shippingConfig()
+	output := &memoryOutput{failing: true}
+
+	shipper := Services.NewLogShipper(config, dir)
+	shipper.AddOutput(output, nil, "")
+	shipper.Start()
+	shipper.Ship(entry("app", Config.LogLevelInfo, "first"))
+	shipper.Ship(entry("app", Config.LogLevelInfo, "second"))
+	shipper.Ship(entry("app", Config.LogLevelInfo, "third"))
+	require.NoError(t, shipper.Close())
+
+	stats := shipper.Stats()[0]
+	assert.Equal(t, int64(3), stats.Spilled)
+	assert.Greater(t, stats.BufferBytes, int64(0))
+	assert.Empty(t, output.messages())
+
+	// 重启后从磁盘缓冲按顺序重放
+	output.setFailing(false)
+	resumed := Services.NewLogShipper(config, dir)
+	resumed.AddOutput(output, nil, "")
+	resumed.Start()
+	require.Eventually(t, func() bool { return len(output.messages()) == 3 }, 2*time.Second, 10*time.Millisecond)
+	require.NoError(t, resumed.Close())
+
+	assert.Equal(t, []string{"first", "second", "third"}, output.messages())
+	stats = resumed.Stats()[0]
+	assert.Equal(t, int64(3), stats.Replayed)
+	assert.Equal(t, int64(0), stats.BufferBytes)
+}
+
+func TestLogShipperQueueFullSpillsToDisk(t *testing.T) {
+	config := shippingConfig()
+	config.QueueSize = 1
+	output := &memoryOutput{}
+
+	// 未启动时队列不会被消费，超出容量的日志应溢出到磁盘
+	shipper := Services.NewLogShipper(config, t.TempDir())
+	shipper.AddOutput(output, nil, "")
+	for i := 0; i < 5; i++ {
+		shipper.Ship(entry("app", Config.LogLevelInfo, "message"))
+	}
+	stats := shipper.Stats()[0]
+	assert.Equal(t, 1, stats.Queued)
+	assert.Equal(t, int64(4), stats.Spilled)
+
+	shipper.Start()
+	require.Eventually(t, func() bool { return len(output.messages()) == 5 }, 2*time.Second, 10*time.Millisecond)
+	require.NoError(t, shipper.Close())
+}
+
+func TestElasticsearchLogOutput(t *testing.T) {
+	var mu sync.Mutex
+	var indices []string
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		auth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		for scanner.Scan() {
+			var action map[string]map[string]string
+			json.Unmarshal(scanner.Bytes(), &action)
+			indices = append(indices, action["create"]["_index"])
+			scanner.Scan()
+			assert.Contains(t, scanner.Text(), `"@timestamp"`)
+		}
+		// 第二条为重复文档，409 视为成功
+		w.Write([]byte(`{"errors":true,"items":[{"create":{"status":201}},{"create":{"status":409}}]}`))
+	}))
+	defer server.Close()
+
+	output := Services.NewElasticsearchLogOutput(&Config.LogShippingElasticsearchConfig{
+		Addresses: []string{"http://127.0.0.1:1", server.URL},
+		APIKey:    "secret",
+		Index:     "logs-{logger}-{date}",
+	})
+	timestamp := time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC)
+	err := output.Send(context.Background(), []Services.LogEntry{
+		{Logger: "Audit", Level: Config.LogLevelInfo, Message: "a", Timestamp: timestamp},
+		{Logger: "Audit", Level: Config.LogLevelInfo, Message: "a", Timestamp: timestamp},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"logs-audit-2024.03.05", "logs-audit-2024.03.05"}, indices)
+	assert.Equal(t, "ApiKey secret", auth)
+}
+
+func TestLokiLogOutput(t *testing.T) {
+	var payload struct {
+		Streams []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"streams"`
+	}
+	var tenant string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/loki/api/v1/push", r.URL.Path)
+		tenant = r.Header.Get("X-Scope-OrgID")
+		json.NewDecoder(r.Body).Decode(&payload)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	output := Services.NewLokiLogOutput(&Config.LogShippingLokiConfig{
+		URL:      server.URL,
+		TenantID: "team-a",
+		Labels:   []string{"app=api", "env = test"},
+	})
+	now := time.Now()
+	err := output.Send(context.Background(), []Services.LogEntry{
+		{Logger: "error", Level: Config.LogLevelError, Message: "later", Timestamp: now.Add(time.Second)},
+		{Logger: "error", Level: Config.LogLevelError, Message: "earlier", Timestamp: now},
+		{Logger: "audit", Level: Config.LogLevelInfo, Message: "audit", Timestamp: now},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "team-a", tenant)
+	require.Len(t, payload.Streams, 2)
+	first := payload.Streams[0]
+	assert.Equal(t, map[string]string{"app": "api", "env": "test", "logger": "error", "level": "error"}, first.Stream)
+	require.Len(t, first.Values, 2)
+	assert.Contains(t, first.Values[0][1], "earlier")
+}
+
+func TestSyslogLogOutput(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	output := Services.NewSyslogLogOutput(&Config.LogShippingSyslogConfig{
+		Network:  "udp",
+		Address:  conn.LocalAddr().String(),
+		Tag:      "api",
+		Facility: 16,
+	})
+	defer output.Close()
+
+	require.NoError(t, output.Send(context.Background(), []Services.LogEntry{entry("security", Config.LogLevelError, "denied")}))
+
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+
+	message := string(buf[:n])
+	// local0(16)*8 + error(3) = 131
+	assert.True(t, strings.HasPrefix(message, "<131>1 "), message)
+	assert.Contains(t, message, " api ")
+	assert.Contains(t, message, " security - ")
+	assert.Contains(t, message, `"message":"denied"`)
+}
@@ -0,0 +1,185 @@
+package LoginHistory
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Testing"
+	"cloud-platform-api/app/Utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	chromeWindows = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+	safariIPhone  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func setupDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "login_history.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.User{}, &Models.LoginAttempt{}))
+	previous := Database.DB
+	Database.DB = db
+	t.Cleanup(func() { Database.DB = previous })
+	return db
+}
+
+// fakeMailer 记录发送的邮件
+type fakeMailer struct {
+	sent chan string
+}
+
+func (m *fakeMailer) SendNotificationEmail(to, subject, body string) error {
+	m.sent <- to + "|" + subject + "|" + body
+	return nil
+}
+
+func (m *fakeMailer) expectNone(t *testing.T) {
+	select {
+	case mail := <-m.sent:
+		t.Fatalf("不应发送邮件: %s", mail)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestParseUserAgent(t *testing.T) {
+	cases := map[string]string{
+		chromeWindows: "Chrome on Windows",
+		safariIPhone:  "Safari on iOS",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0": "Edge on Windows",
+		"Mozilla/5.0 (Linux; Android 14) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36":                  "Chrome on Android",
+		"Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0":                                                "Firefox on Linux",
+		"": "Unknown on Unknown",
+	}
+	for ua, expected := range cases {
+		assert.Equal(t, expected, Utils.ParseUserAgent(ua).String(), ua)
+	}
+}
+
+func TestRecordDetectsNewDeviceAndCountry(t *testing.T) {
+	db := setupDB(t)
+	user := Testing.NewFactory(t, db).User()
+	mailer := &fakeMailer{sent: make(chan string, 10)}
+	service := Services.NewLoginHistoryService(db, &Config.LoginNotificationConfig{
+		EmailEnabled: true, CountryHeader: "CF-IPCountry", KnownWindow: 30 * 24 * time.Hour,
+	}, mailer)
+	record := func(ua, country string, success bool) bool {
+		newDevice, err := service.Record(context.Background(), Services.LoginRecord{
+			Username: user.Username, IPAddress: "203.0.113.7", UserAgent: ua, Country: country, Success: success,
+		})
+		require.NoError(t, err)
+		return newDevice
+	}
+
+	assert.False(t, record(chromeWindows, "cn", true), "首次登录不提醒")
+	assert.False(t, record(chromeWindows, "CN", true), "已知设备和国家")
+	assert.False(t, record(safariIPhone, "CN", false), "失败的登录不提醒")
+	mailer.expectNone(t)
+
+	assert.True(t, record(safariIPhone, "CN", true), "新设备")
+	mail := <-mailer.sent
+	assert.True(t, strings.HasPrefix(mail, user.Email+"|新设备登录提醒|"))
+	assert.Contains(t, mail, "Safari on iOS")
+
+	assert.True(t, record(chromeWindows, "US", true), "新国家")
+	assert.Contains(t, <-mailer.sent, "US")
+	assert.False(t, record(chromeWindows, "", true), "没有国家信息时只按设备判断")
+
+	// 超出已知时间窗口后视为新设备
+	service.SetClock(func() time.Time { return time.Now().Add(31 * 24 * time.Hour) })
+	assert.True(t, record(chromeWindows, "CN", true))
+	<-mailer.sent
+
+	var attempts []Models.LoginAttempt
+	require.NoError(t, db.Order("id").Find(&attempts).Error)
+	require.Len(t, attempts, 7)
+	assert.Equal(t, "CN", attempts[0].Location)
+	assert.Equal(t, "Chrome on Windows", attempts[0].DeviceInfo)
+	assert.False(t, attempts[2].Success)
+}
+
+func TestMyLoginsAPI(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-jwt-secret-key-for-testing-only-32-chars")
+	Config.LoadConfig()
+	db := setupDB(t)
+	mailer := &fakeMailer{sent: make(chan string, 10)}
+	service := Services.NewLoginHistoryService(db, &Config.LoginNotificationConfig{
+		EmailEnabled: true, CountryHeader: "CF-IPCountry", KnownWindow: time.Hour,
+	}, mailer)
+	Services.SetDefaultLoginHistoryService(service)
+	t.Cleanup(func() { Services.SetDefaultLoginHistoryService(nil) })
+
+	engine := gin.New()
+	engine.POST("/api/v1/auth/login", Controllers.NewAuthController().Login)
+	userGroup := engine.Group("/api/v1/users", Middleware.NewAuthMiddleware().Handle())
+	userGroup.GET("/me/logins", Controllers.NewUserController().GetMyLogins)
+	userGroup.GET("/:id", func(c *gin.Context) { c.String(http.StatusOK, c.Param("id")) })
+
+	factory := Testing.NewFactory(t, db)
+	user := factory.User()
+	other := factory.User()
+	login := func(username, password, ua, country string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login",
+			strings.NewReader(fmt.Sprintf(`{"username":%q,"password":%q}`, username, password)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", ua)
+		req.Header.Set("CF-IPCountry", country)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusOK, login(user.Username, Testing.DefaultPassword, chromeWindows, "CN"))
+	require.Equal(t, http.StatusUnauthorized, login(user.Username, "wrong-password", chromeWindows, "CN"))
+	require.Equal(t, http.StatusOK, login(user.Username, Testing.DefaultPassword, safariIPhone, "JP"))
+	require.Equal(t, http.StatusOK, login(other.Username, Testing.DefaultPassword, chromeWindows, "CN"))
+	assert.Contains(t, <-mailer.sent, "Safari on iOS")
+	mailer.expectNone(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/me/logins?limit=2", nil)
+	req.Header.Set("Authorization", "Bearer "+factory.Token(user))
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Data []Services.LoginHistoryEntry `json:"data"`
+		Meta Utils.PageMeta               `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 2)
+	assert.Equal(t, "Safari on iOS", resp.Data[0].Device)
+	assert.Equal(t, "JP", resp.Data[0].Location)
+	assert.True(t, resp.Data[0].Success)
+	assert.False(t, resp.Data[1].Success)
+	assert.NotEmpty(t, resp.Data[1].FailureReason)
+	require.NotNil(t, resp.Meta.Total)
+	assert.Equal(t, int64(3), *resp.Meta.Total)
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/users/me/logins", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,412 @@
+package Mail
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeDriver 按顺序返回预设的错误，用完后发送成功
+type fakeDriver struct {
+	mu     sync.Mutex
+	errs   []error
+	sent   []*Services.Mail
+	calls  int
+	always error
+}
+
+func (d *fakeDriver) Name() string {
+	return "fake"
+}
+
+func (d *fakeDriver) Send(ctx context.Context, message *Services.Mail) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.calls++
+	if d.always != nil {
+		return "", d.always
+	}
+	if len(d.errs) > 0 {
+		err := d.errs[0]
+		d.errs = d.errs[1:]
+		return "", err
+	}
+	d.sent = append(d.sent, message)
+	// 与 SendGrid 的 X-Message-Id 一样不含点号
+	return "provider-" + strings.SplitN(message.ID, "@", 2)[0], nil
+}
+
+func (d *fakeDriver) callCount() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.calls
+}
+
+func setupMailDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "mail.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.MailMessage{}, &Models.MailSuppression{}))
+	return db
+}
+
+func mailConfig() *Config.EmailConfig {
+	return &Config.EmailConfig{
+		Driver:       Config.MailDriverLog,
+		From:         "noreply@example.com",
+		FromName:     "Cloud Platform",
+		Timeout:      time.Second,
+		QueueWorkers: 2,
+		QueueSize:    10,
+		MaxRetries:   2,
+		RetryDelay:   10 * time.Millisecond,
+	}
+}
+
+func newMailService(t *testing.T, config *Config.EmailConfig, driver Services.MailDriver) (*Services.MailService, *gorm.DB) {
+	db := setupMailDB(t)
+	service := Services.NewMailService(db, config)
+	service.SetDriver(driver)
+	return service, db
+}
+
+func waitForStatus(t *testing.T, service *Services.MailService, id uint, status string) *Models.MailMessage {
+	var message *Models.MailMessage
+	require.Eventually(t, func() bool {
+		var err error
+		message, err = service.GetMessage(id)
+		return err == nil && message.Status == status
+	}, 3*time.Second, 10*time.Millisecond, "邮件状态未变为 %s", status)
+	return message
+}
+
+func TestMailQueueDelivery(t *testing.T) {
+	driver := &fakeDriver{}
+	service, _ := newMailService(t, mailConfig(), driver)
+	service.Start()
+	defer service.Stop()
+
+	record, err := service.Queue(&Services.Mail{To: []string{"Alice <alice@example.com>"}, Subject: "hello", HTML: "<p>hi</p>"})
+	require.NoError(t, err)
+	assert.Equal(t, Models.MailStatusQueued, record.Status)
+	assert.Equal(t, "alice@example.com", record.Recipients)
+
+	sent := waitForStatus(t, service, record.ID, Models.MailStatusSent)
+	assert.Equal(t, 1, sent.Attempts)
+	assert.Equal(t, "provider-"+strings.SplitN(record.MessageID, "@", 2)[0], sent.ProviderMessageID)
+	assert.NotNil(t, sent.SentAt)
+	require.Len(t, driver.sent, 1)
+	assert.Equal(t, "noreply@example.com", driver.sent[0].From)
+	assert.Equal(t, "Cloud Platform", driver.sent[0].FromName)
+
+	_, err = service.Queue(&Services.Mail{Subject: "empty"})
+	assert.ErrorIs(t, err, Services.ErrMailNoRecipients)
+	_, err = service.Queue(&Services.Mail{To: []string{"not-an-address"}})
+	assert.Error(t, err)
+}
+
+func TestMailRetryWithBackoff(t *testing.T) {
+	driver := &fakeDriver{errs: []error{errors.New("connection reset")}}
+	service, _ := newMailService(t, mailConfig(), driver)
+	service.Start()
+	defer service.Stop()
+
+	record, err := service.Queue(&Services.Mail{To: []string{"bob@example.com"}, Subject: "retry", Text: "body"})
+	require.NoError(t, err)
+
+	sent := waitForStatus(t, service, record.ID, Models.MailStatusSent)
+	assert.Equal(t, 2, sent.Attempts)
+	assert.Empty(t, sent.LastError)
+
+	// 重试次数用尽后标记为失败，手动重试后重新发送
+	driver.always = errors.New("timeout")
+	record, err = service.Queue(&Services.Mail{To: []string{"carol@example.com"}, Subject: "fail", Text: "body"})
+	require.NoError(t, err)
+	failed := waitForStatus(t, service, record.ID, Models.MailStatusFailed)
+	assert.Equal(t, 3, failed.Attempts)
+	assert.Contains(t, failed.LastError, "timeout")
+
+	driver.mu.Lock()
+	driver.always = nil
+	driver.mu.Unlock()
+	_, err = service.Retry(record.ID)
+	require.NoError(t, err)
+	resent := waitForStatus(t, service, record.ID, Models.MailStatusSent)
+	assert.Equal(t, 1, resent.Attempts)
+
+	_, err = service.Retry(record.ID)
+	assert.ErrorIs(t, err, Services.ErrMailNotRetryable)
+}
+
+func TestMailPermanentFailureSuppressesRecipient(t *testing.T) {
+	driver := &fakeDriver{always: &Services.MailDeliveryError{Permanent: true, Recipient: "gone@example.com", Err: errors.New("550 user unknown")}}
+	service, _ := newMailService(t, mailConfig(), driver)
+
+	record, err := service.Send(context.Background(), &Services.Mail{To: []string{"gone@example.com"}, Subject: "x", Text: "x"})
+	require.Error(t, err)
+	assert.Equal(t, Models.MailStatusFailed, record.Status)
+	assert.Equal(t, 1, driver.callCount())
+	assert.True(t, service.IsSuppressed("GONE@example.com"))
+
+	// 已屏蔽的地址不再发送
+	record, err = service.Queue(&Services.Mail{To: []string{"gone@example.com"}, Subject: "x", Text: "x"})
+	require.NoError(t, err)
+	assert.Equal(t, Models.MailStatusSuppressed, record.Status)
+
+	// 部分收件人被屏蔽时只发给其余收件人
+	driver.always = nil
+	record, err = service.Send(context.Background(), &Services.Mail{
+		To:   []string{"ok@example.com"},
+		Bcc:  []string{"gone@example.com"},
+		Text: "x",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Models.MailStatusSent, record.Status)
+	require.Len(t, driver.sent, 1)
+	assert.Empty(t, driver.sent[0].Bcc)
+
+	require.NoError(t, service.Unsuppress("gone@example.com"))
+	assert.False(t, service.IsSuppressed("gone@example.com"))
+	assert.ErrorIs(t, service.Unsuppress("gone@example.com"), Services.ErrMailSuppressionNotFound)
+}
+
+func TestMailTemplates(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "layouts"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "welcome.html"), []byte(
+		`{{define "subject"}}欢迎 {{.Name}}{{end}}{{define "content"}}<p>你好 {{.Name}}</p>{{end}}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "layouts", "brand.html"), []byte(
+		`<div class="brand"><h1>{{.Subject}}</h1>{{.Content}}</div>`), 0644))
+
+	config := mailConfig()
+	config.TemplatePath = dir
+	driver := &fakeDriver{}
+	service, _ := newMailService(t, config, driver)
+
+	record, err := service.Send(context.Background(), &Services.Mail{
+		To:       []string{"dave@example.com"},
+		Template: "welcome",
+		Layout:   "brand",
+		Data:     map[string]interface{}{"Name": "<Dave>"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "欢迎 <Dave>", record.Subject)
+	assert.Equal(t, "welcome", record.Template)
+	require.Len(t, driver.sent, 1)
+	assert.Equal(t, `<div class="brand"><h1>欢迎 &lt;Dave&gt;</h1><p>你好 &lt;Dave&gt;</p></div>`, driver.sent[0].HTML)
+	assert.Equal(t, "你好 <Dave>", driver.sent[0].Text)
+
+	// 默认布局为内置布局
+	_, html, _, err := service.Renderer().Render("welcome", "", map[string]interface{}{"Name": "Eve"})
+	require.NoError(t, err)
+	assert.Contains(t, html, "<!DOCTYPE html>")
+	assert.Contains(t, html, "<p>你好 Eve</p>")
+
+	_, _, _, err = service.Renderer().Render("../secret", "", nil)
+	assert.ErrorIs(t, err, Services.ErrMailTemplateNotFound)
+	_, err = service.Queue(&Services.Mail{To: []string{"dave@example.com"}, Template: "missing"})
+	assert.ErrorIs(t, err, Services.ErrMailTemplateNotFound)
+}
+
+func TestSendGridDriver(t *testing.T) {
+	var payload map[string]interface{}
+	status := http.StatusAccepted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/mail/send", r.URL.Path)
+		assert.Equal(t, "Bearer sg-key", r.Header.Get("Authorization"))
+		json.NewDecoder(r.Body).Decode(&payload)
+		w.Header().Set("X-Message-Id", "sg-123")
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	config := mailConfig()
+	config.Driver = Config.MailDriverSendGrid
+	config.APIKey = "sg-key"
+	config.Endpoint = server.URL
+	driver, err := Services.NewMailDriver(config, nil)
+	require.NoError(t, err)
+
+	message := (&Services.Mail{From: "a@example.com", To: []string{"b@example.com"}, Subject: "s", HTML: "<b>x</b>"}).
+		Attach("report.csv", "text/csv", []byte("a,b"))
+	id, err := driver.Send(context.Background(), message)
+	require.NoError(t, err)
+	assert.Equal(t, "sg-123", id)
+	assert.Equal(t, "s", payload["subject"])
+	attachments := payload["attachments"].([]interface{})
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("a,b")), attachments[0].(map[string]interface{})["content"])
+
+	status = http.StatusBadRequest
+	_, err = driver.Send(context.Background(), message)
+	assert.True(t, Services.IsPermanentMailError(err))
+	status = http.StatusTooManyRequests
+	_, err = driver.Send(context.Background(), message)
+	require.Error(t, err)
+	assert.False(t, Services.IsPermanentMailError(err))
+}
+
+func TestMailgunDriver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/mg.example.com/messages", r.URL.Path)
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "api", user)
+		assert.Equal(t, "mg-key", pass)
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		assert.Equal(t, []string{"b@example.com", "c@example.com"}, r.MultipartForm.Value["to"])
+		assert.Equal(t, "s", r.FormValue("subject"))
+		assert.Len(t, r.MultipartForm.File["attachment"], 1)
+		w.Write([]byte(`{"id":"<mg-1@mg.example.com>","message":"Queued. Thank you."}`))
+	}))
+	defer server.Close()
+
+	config := mailConfig()
+	config.Driver = Config.MailDriverMailgun
+	config.APIKey = "mg-key"
+	config.Domain = "mg.example.com"
+	config.Endpoint = server.URL
+	driver, err := Services.NewMailDriver(config, nil)
+	require.NoError(t, err)
+
+	message := (&Services.Mail{From: "a@example.com", To: []string{"b@example.com", "c@example.com"}, Subject: "s", Text: "x"}).
+		Attach("a.txt", "text/plain", []byte("hello"))
+	id, err := driver.Send(context.Background(), message)
+	require.NoError(t, err)
+	assert.Equal(t, "mg-1@mg.example.com", id)
+}
+
+func TestSESDriverSendsSignedRawMessage(t *testing.T) {
+	var raw []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/email/outbound-emails", r.URL.Path)
+		auth := r.Header.Get("Authorization")
+		assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/"), auth)
+		assert.Contains(t, auth, "/us-east-1/ses/aws4_request")
+		assert.NotEmpty(t, r.Header.Get("X-Amz-Date"))
+
+		var body struct {
+			Destination map[string][]string
+			Content     struct{ Raw struct{ Data string } }
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, []string{"hidden@example.com"}, body.Destination["BccAddresses"])
+		raw, _ = base64.StdEncoding.DecodeString(body.Content.Raw.Data)
+		w.Write([]byte(`{"MessageId":"ses-1"}`))
+	}))
+	defer server.Close()
+
+	config := mailConfig()
+	config.Driver = Config.MailDriverSES
+	config.Region = "us-east-1"
+	config.AccessKeyID = "AKID"
+	config.SecretAccessKey = "secret"
+	config.Endpoint = server.URL
+	driver, err := Services.NewMailDriver(config, nil)
+	require.NoError(t, err)
+
+	message := (&Services.Mail{
+		ID:      "abc@example.com",
+		From:    "a@example.com",
+		To:      []string{"b@example.com"},
+		Bcc:     []string{"hidden@example.com"},
+		Subject: "报表",
+		HTML:    "<p>见附件</p>",
+		Text:    "见附件",
+	}).Attach("报表.pdf", "application/pdf", []byte("%PDF-1.4"))
+	id, err := driver.Send(context.Background(), message)
+	require.NoError(t, err)
+	assert.Equal(t, "ses-1", id)
+
+	// 原始邮件：密送不出现在头部，附件可以正确解析
+	parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+	require.NoError(t, err)
+	assert.Empty(t, parsed.Header.Get("Bcc"))
+	assert.Equal(t, "<abc@example.com>", parsed.Header.Get("Message-ID"))
+	subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	assert.Equal(t, "报表", subject)
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	body, err := reader.NextPart()
+	require.NoError(t, err)
+	assert.Contains(t, body.Header.Get("Content-Type"), "multipart/alternative")
+	attachment, err := reader.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "报表.pdf", attachment.FileName())
+	content, _ := io.ReadAll(base64.NewDecoder(base64.StdEncoding, attachment))
+	assert.Equal(t, "%PDF-1.4", string(content))
+}
+
+func TestMailBounceWebhooks(t *testing.T) {
+	driver := &fakeDriver{}
+	service, db := newMailService(t, mailConfig(), driver)
+
+	record, err := service.Send(context.Background(), &Services.Mail{To: []string{"bounce@example.com"}, Text: "x"})
+	require.NoError(t, err)
+	require.Equal(t, Models.MailStatusSent, record.Status)
+
+	sendgrid := `[
+		{"event":"bounce","type":"bounce","email":"bounce@example.com","reason":"550 5.1.1","sg_message_id":"` + record.ProviderMessageID + `.filter0001"},
+		{"event":"bounce","type":"blocked","email":"soft@example.com"},
+		{"event":"delivered","email":"fine@example.com"}
+	]`
+	count, err := service.HandleBounceWebhook(Config.MailDriverSendGrid, []byte(sendgrid))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.True(t, service.IsSuppressed("bounce@example.com"))
+	assert.False(t, service.IsSuppressed("soft@example.com"))
+	bounced, _ := service.GetMessage(record.ID)
+	assert.Equal(t, Models.MailStatusBounced, bounced.Status)
+
+	mailgun := `{"event-data":{"event":"complained","recipient":"angry@example.com"}}`
+	count, err = service.HandleBounceWebhook(Config.MailDriverMailgun, []byte(mailgun))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	inner, _ := json.Marshal(map[string]interface{}{
+		"notificationType": "Bounce",
+		"bounce": map[string]interface{}{
+			"bounceType":        "Permanent",
+			"bouncedRecipients": []map[string]string{{"emailAddress": "ses@example.com", "diagnosticCode": "smtp; 550"}},
+		},
+		"mail": map[string]string{"messageId": "unknown"},
+	})
+	ses, _ := json.Marshal(map[string]string{"Type": "Notification", "Message": string(inner)})
+	count, err = service.HandleBounceWebhook(Config.MailDriverSES, ses)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	var suppression Models.MailSuppression
+	require.NoError(t, db.Where("email = ?", "angry@example.com").First(&suppression).Error)
+	assert.Equal(t, Models.MailSuppressionComplaint, suppression.Reason)
+	assert.Equal(t, Config.MailDriverMailgun, suppression.Source)
+
+	_, err = service.HandleBounceWebhook("postmark", []byte("{}"))
+	assert.ErrorIs(t, err, Services.ErrUnsupportedMailProvider)
+}
@@ -0,0 +1,228 @@
+package Metering
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Testing"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func setupDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "metering.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.User{}, &Models.UsageRecord{}, &Models.UsageQuota{}, &Models.UserSetting{}))
+	previous := Database.DB
+	Database.DB = db
+	t.Cleanup(func() { Database.DB = previous })
+	return db
+}
+
+func newService(db *gorm.DB, config Config.MeteringConfig) *Services.MeteringService {
+	config.Enabled = true
+	if config.Period == "" {
+		config.Period = Config.MeteringPeriodMonth
+	}
+	return Services.NewMeteringService(db, &config)
+}
+
+func TestReserveQuotaAndPeriodReset(t *testing.T) {
+	db := setupDB(t)
+	service := newService(db, Config.MeteringConfig{QuotaAPICalls: 2})
+	now := time.Date(2024, 1, 31, 23, 0, 0, 0, time.UTC)
+	service.SetClock(func() time.Time { return now })
+	tenant := Services.UserTenant(1)
+
+	require.NoError(t, service.Reserve(tenant, Services.UsageMetricAPICalls, 1))
+	require.NoError(t, service.Reserve(tenant, Services.UsageMetricAPICalls, 1))
+	err := service.Reserve(tenant, Services.UsageMetricAPICalls, 1)
+	var quotaErr *Services.UsageQuotaError
+	require.True(t, errors.As(err, &quotaErr))
+	assert.Equal(t, int64(2), quotaErr.Used)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), quotaErr.ResetAt)
+	assert.Error(t, service.Check(tenant, Services.UsageMetricAPICalls, 1))
+
+	// 不限额的指标和其他租户不受影响
+	require.NoError(t, service.Reserve(tenant, Services.UsageMetricStorageBytes, 1<<30))
+	require.NoError(t, service.Reserve(Services.UserTenant(2), Services.UsageMetricAPICalls, 1))
+
+	// 新周期重新计算用量
+	now = now.Add(2 * time.Hour)
+	require.NoError(t, service.Reserve(tenant, Services.UsageMetricAPICalls, 1))
+	summaries, err := service.Summary(tenant)
+	require.NoError(t, err)
+	require.Len(t, summaries, len(Services.UsageMetrics))
+	assert.Equal(t, Services.UsageMetricAPICalls, summaries[0].Metric)
+	assert.Equal(t, int64(1), summaries[0].Used)
+	assert.Equal(t, int64(1), summaries[0].Remaining)
+	assert.Equal(t, int64(-1), summaries[1].Remaining, "不限额时剩余为-1")
+}
+
+func TestFlushRecordsAndExport(t *testing.T) {
+	db := setupDB(t)
+	service := newService(db, Config.MeteringConfig{})
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	service.SetClock(func() time.Time { return now })
+	tenant := Services.SourceTenant("billing")
+
+	service.Record(tenant, Services.UsageMetricMetricsIngested, 5)
+	require.NoError(t, service.Flush())
+	service.Record(tenant, Services.UsageMetricMetricsIngested, 3)
+	now = now.Add(24 * time.Hour)
+	service.Record(tenant, Services.UsageMetricMetricsIngested, 7)
+
+	// 查询前写入剩余计数，同一天的计数累加到同一条记录
+	records, err := service.Records(Services.UsageFilter{Tenant: tenant})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "2024-03-01", records[0].Date)
+	assert.Equal(t, int64(8), records[0].Quantity)
+	assert.Equal(t, int64(7), records[1].Quantity)
+
+	records, err = service.Records(Services.UsageFilter{Tenant: tenant, From: now, To: now})
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+
+	var buf bytes.Buffer
+	require.NoError(t, service.ExportCSV(&buf, Services.UsageFilter{Metric: Services.UsageMetricMetricsIngested}))
+	assert.Equal(t, "date,tenant,metric,quantity\n"+
+		"2024-03-01,source:billing,metrics_ingested,8\n"+
+		"2024-03-02,source:billing,metrics_ingested,7\n", buf.String())
+
+	// 新实例从数据库加载本周期用量
+	reloaded := newService(db, Config.MeteringConfig{QuotaMetricsIngested: 20})
+	reloaded.SetClock(func() time.Time { return now })
+	assert.Error(t, reloaded.Check(tenant, Services.UsageMetricMetricsIngested, 6))
+	assert.NoError(t, reloaded.Check(tenant, Services.UsageMetricMetricsIngested, 5))
+}
+
+func TestQuotaOverride(t *testing.T) {
+	db := setupDB(t)
+	service := newService(db, Config.MeteringConfig{QuotaStorageBytes: 100})
+	tenant := Services.UserTenant(1)
+
+	require.NoError(t, service.Reserve(tenant, Services.UsageMetricStorageBytes, 80))
+	assert.Error(t, service.Check(tenant, Services.UsageMetricStorageBytes, 50))
+
+	_, err := service.SetQuota(tenant, Services.UsageMetricStorageBytes, -1, 9)
+	assert.ErrorIs(t, err, Services.ErrInvalidUsageQuota)
+	quota, err := service.SetQuota(tenant, Services.UsageMetricStorageBytes, 1000, 9)
+	require.NoError(t, err)
+	assert.Equal(t, uint(9), quota.UpdatedBy)
+	assert.NoError(t, service.Check(tenant, Services.UsageMetricStorageBytes, 50))
+
+	// 设置为0表示不限制
+	_, err = service.SetQuota(tenant, Services.UsageMetricStorageBytes, 0, 9)
+	require.NoError(t, err)
+	assert.NoError(t, service.Check(tenant, Services.UsageMetricStorageBytes, 1<<40))
+	quotas, err := service.Quotas(tenant)
+	require.NoError(t, err)
+	assert.Len(t, quotas, 1)
+
+	// 删除后恢复默认限额
+	require.NoError(t, service.DeleteQuota(tenant, Services.UsageMetricStorageBytes))
+	assert.Error(t, service.Check(tenant, Services.UsageMetricStorageBytes, 50))
+}
+
+func TestAuthenticatedRequestsAreMetered(t *testing.T) {
+	db := setupDB(t)
+	t.Setenv("JWT_SECRET", "test-jwt-secret-key-for-testing-only-32-chars")
+	Config.LoadConfig()
+	factory := Testing.NewFactory(t, db)
+	user := factory.User()
+	token := factory.Token(user)
+
+	service := newService(db, Config.MeteringConfig{QuotaAPICalls: 2})
+	Services.SetDefaultMeteringService(service)
+	t.Cleanup(func() { Services.SetDefaultMeteringService(nil) })
+
+	engine := gin.New()
+	engine.GET("/api/v1/usage", Middleware.NewAuthMiddleware().Handle(), Controllers.NewUsageController(service).GetMyUsage)
+	request := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/usage", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request()
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var body struct {
+		Data struct {
+			Tenant string                  `json:"tenant"`
+			Usage  []Services.UsageSummary `json:"usage"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, Services.UserTenant(user.ID), body.Data.Tenant)
+	assert.Equal(t, int64(1), body.Data.Usage[0].Used)
+
+	require.Equal(t, http.StatusOK, request().Code)
+	w = request()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "QUOTA_EXCEEDED")
+}
+
+func TestNotificationsOverQuotaAreNotSent(t *testing.T) {
+	db := setupDB(t)
+	user := Testing.NewFactory(t, db).User()
+	Services.SetDefaultUserSettingsService(Services.NewUserSettingsService(db, &Config.UserSettingsConfig{
+		NotifyInApp: true, NotifyEmail: true, Timezone: "UTC", DashboardTimeRange: "24h", DashboardRefreshSeconds: 60,
+	}, &Config.I18nConfig{DefaultLocale: "zh-CN", Supported: []string{"zh-CN"}}))
+	t.Cleanup(func() { Services.SetDefaultUserSettingsService(nil) })
+
+	service := newService(db, Config.MeteringConfig{QuotaNotificationsSent: 1})
+	mailer := &fakeMailer{}
+	dispatcher := Services.NewUserNotificationDispatcher(db, mailer)
+	dispatcher.SetMeteringService(service)
+	notification := Services.UserNotification{EmailSubject: "提醒", EmailBody: "<p>内容</p>"}
+
+	result := dispatcher.Dispatch(context.Background(), user, notification)
+	assert.Equal(t, []string{Services.NotificationChannelEmail}, result.Delivered)
+	result = dispatcher.Dispatch(context.Background(), user, notification)
+	assert.Empty(t, result.Delivered)
+	var quotaErr *Services.UsageQuotaError
+	assert.True(t, errors.As(result.Failures[Services.NotificationChannelEmail], &quotaErr))
+	assert.Equal(t, 1, mailer.sent)
+
+	var buf bytes.Buffer
+	require.NoError(t, service.ExportCSV(&buf, Services.UsageFilter{Tenant: Services.UserTenant(user.ID)}))
+	assert.True(t, strings.HasSuffix(buf.String(), ",notifications_sent,1\n"), buf.String())
+}
+
+// fakeMailer 统计发送的邮件数
+type fakeMailer struct {
+	sent int
+}
+
+func (m *fakeMailer) SendNotificationEmail(to, subject, body string) error {
+	m.sent++
+	return nil
+}
@@ -0,0 +1,291 @@
+package Middleware
+
+import (
+	"bufio"
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Middleware"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// compressionConfig 测试使用的压缩配置
+func compressionConfig() *Config.CompressionConfig {
+	return &Config.CompressionConfig{
+		Enabled:      true,
+		Algorithms:   "br,gzip",
+		MinSize:      256,
+		ContentTypes: "application/json,text/*",
+		ExcludePaths: "/ws",
+		GzipLevel:    5,
+		BrotliLevel:  4,
+	}
+}
+
+// newCompressionRouter 创建使用响应压缩的路由
+func newCompressionRouter(t testing.TB, config *Config.CompressionConfig) *gin.Engine {
+	t.Helper()
+	require.NoError(t, config.Validate())
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware.NewCompressionMiddleware(config).Handle())
+	large := strings.Repeat("compressible payload ", 100)
+	router.GET("/large", func(c *gin.Context) {
+		c.Header("ETag", `"v1"`)
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": large})
+	})
+	router.GET("/small", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	})
+	router.GET("/image", func(c *gin.Context) {
+		c.Data(http.StatusOK, "image/png", []byte(large))
+	})
+	router.GET("/empty", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	router.GET("/ws/echo", func(c *gin.Context) {
+		c.String(http.StatusOK, large)
+	})
+	router.GET("/events", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		for i := 0; i < 3; i++ {
+			c.SSEvent("log", large)
+			c.Writer.Flush()
+		}
+	})
+	return router
+}
+
+// compressionGet 发送请求并返回响应
+func compressionGet(router http.Handler, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// decompress 按 Content-Encoding 解压响应体
+func decompress(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var reader io.Reader
+	switch encoding {
+	case "gzip":
+		gz, err := gzip.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		reader = gz
+	case "br":
+		reader = brotli.NewReader(bytes.NewReader(body))
+	default:
+		return string(body)
+	}
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestCompressionNegotiatesEncoding(t *testing.T) {
+	router := newCompressionRouter(t, compressionConfig())
+	plain := compressionGet(router, "/large", nil)
+	require.Equal(t, http.StatusOK, plain.Code)
+	assert.Empty(t, plain.Header().Get("Content-Encoding"))
+	assert.Contains(t, plain.Header().Get("Vary"), "Accept-Encoding")
+
+	for header, expected := range map[string]string{
+		"gzip, deflate, br":    "br",
+		"gzip":                 "gzip",
+		"br;q=0.5, gzip;q=0.8": "gzip",
+		"*":                    "br",
+		"br;q=0, *;q=0.1":      "gzip",
+		"identity":             "",
+		"gzip;q=0":             "",
+	} {
+		w := compressionGet(router, "/large", map[string]string{"Accept-Encoding": header})
+		require.Equal(t, http.StatusOK, w.Code, header)
+		assert.Equal(t, expected, w.Header().Get("Content-Encoding"), header)
+		assert.Equal(t, plain.Body.String(), decompress(t, expected, w.Body.Bytes()), header)
+		if expected != "" {
+			assert.Less(t, w.Body.Len(), plain.Body.Len(), header)
+			assert.Equal(t, `W/"v1"`, w.Header().Get("ETag"), header)
+		}
+	}
+}
+
+func TestCompressionSkipsIneligibleResponses(t *testing.T) {
+	router := newCompressionRouter(t, compressionConfig())
+	accept := map[string]string{"Accept-Encoding": "gzip"}
+
+	w := compressionGet(router, "/small", accept)
+	assert.Empty(t, w.Header().Get("Content-Encoding"), "小于最小字节数")
+	assert.JSONEq(t, `{"success":true}`, w.Body.String())
+
+	w = compressionGet(router, "/image", accept)
+	assert.Empty(t, w.Header().Get("Content-Encoding"), "内容类型不在允许列表中")
+
+	w = compressionGet(router, "/empty", accept)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+
+	w = compressionGet(router, "/ws/echo", accept)
+	assert.Empty(t, w.Header().Get("Content-Encoding"), "排除的路由分组")
+
+	w = compressionGet(router, "/large", map[string]string{"Accept-Encoding": "gzip", "Range": "bytes=0-10"})
+	assert.Empty(t, w.Header().Get("Content-Encoding"), "Range请求")
+
+	w = compressionGet(router, "/large", map[string]string{"Accept-Encoding": "gzip", "Upgrade": "websocket"})
+	assert.Empty(t, w.Header().Get("Content-Encoding"), "WebSocket升级请求")
+
+	// text/* 不匹配事件流
+	w = compressionGet(router, "/events", accept)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, 3, strings.Count(w.Body.String(), "event:log"))
+}
+
+// TestCompressionStreamsFlushedChunks 显式允许压缩事件流时，每次刷新的数据在处理器结束前到达客户端
+func TestCompressionStreamsFlushedChunks(t *testing.T) {
+	for _, passthrough := range []bool{true, false} {
+		config := compressionConfig()
+		if !passthrough {
+			config.ContentTypes = "text/event-stream"
+		}
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(Middleware.NewCompressionMiddleware(config).Handle())
+		next := make(chan struct{})
+		router.GET("/tail", func(c *gin.Context) {
+			c.Header("Content-Type", "text/event-stream")
+			for i := 0; i < 3; i++ {
+				c.SSEvent("log", fmt.Sprintf("entry-%d", i))
+				c.Writer.Flush()
+				select {
+				case <-next:
+				case <-time.After(5 * time.Second):
+					return
+				}
+			}
+		})
+		server := httptest.NewServer(router)
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/tail", nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept-Encoding", "gzip")
+		client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+
+		var body io.Reader = resp.Body
+		if passthrough {
+			assert.Empty(t, resp.Header.Get("Content-Encoding"))
+		} else {
+			require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+			gz, err := gzip.NewReader(resp.Body)
+			require.NoError(t, err)
+			body = gz
+		}
+		reader := bufio.NewReader(body)
+		for i := 0; i < 3; i++ {
+			// 处理器等待 next 时读到当前事件，说明刷新的数据没有被缓存
+			event, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			assert.Equal(t, "event:log\n", event)
+			data, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf("data:entry-%d\n", i), data)
+			_, err = reader.ReadString('\n')
+			require.NoError(t, err)
+			next <- struct{}{}
+		}
+		resp.Body.Close()
+		server.Close()
+	}
+}
+
+// flushRecorder 记录每次刷新时已写出的字节数
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushed []int
+}
+
+func (r *flushRecorder) Flush() {
+	r.flushed = append(r.flushed, r.Body.Len())
+	r.ResponseRecorder.Flush()
+}
+
+// benchmarkTail 模拟日志尾随的事件流，检查每次刷新都有新的数据写出
+func benchmarkTail(b *testing.B, config *Config.CompressionConfig, events int) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	if config != nil {
+		router.Use(Middleware.NewCompressionMiddleware(config).Handle())
+	}
+	entry := gin.H{"level": "info", "message": strings.Repeat("request completed ", 8), "status": 200}
+	router.GET("/tail", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		for i := 0; i < events; i++ {
+			c.SSEvent("log", entry)
+			c.Writer.Flush()
+		}
+	})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/tail", nil)
+		req.Header.Set("Accept-Encoding", "gzip, br")
+		recorder := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+		router.ServeHTTP(recorder, req)
+		if len(recorder.flushed) != events {
+			b.Fatalf("刷新次数 %d，期望 %d", len(recorder.flushed), events)
+		}
+		previous := 0
+		for _, size := range recorder.flushed {
+			if size <= previous {
+				b.Fatalf("刷新时没有写出新的数据，流式响应被缓存")
+			}
+			previous = size
+		}
+	}
+	b.ReportMetric(float64(events), "flushes/op")
+}
+
+func BenchmarkLogTailWithoutCompression(b *testing.B) {
+	benchmarkTail(b, nil, 100)
+}
+
+func BenchmarkLogTailCompressionExcluded(b *testing.B) {
+	benchmarkTail(b, compressionConfig(), 100)
+}
+
+func BenchmarkLogTailCompressed(b *testing.B) {
+	config := compressionConfig()
+	config.ContentTypes = "text/event-stream"
+	benchmarkTail(b, config, 100)
+}
+
+func BenchmarkCompressionJSON(b *testing.B) {
+	for _, encoding := range []string{"", "gzip", "br"} {
+		b.Run("encoding="+encoding, func(b *testing.B) {
+			router := newCompressionRouter(b, compressionConfig())
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				w := compressionGet(router, "/large", map[string]string{"Accept-Encoding": encoding})
+				if w.Code != http.StatusOK {
+					b.Fatalf("状态码 %d", w.Code)
+				}
+			}
+		})
+	}
+}
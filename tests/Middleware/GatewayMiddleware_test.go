@@ -0,0 +1,173 @@
+package Middleware
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Middleware"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+)
+
+// newGatewayRouter 创建使用网关策略的路由，X-Role 请求头模拟认证中间件设置的用户角色
+func newGatewayRouter(t *testing.T, config *Config.GatewayConfig) *gin.Engine {
+	t.Helper()
+	config.Enabled = true
+	require.NoError(t, config.Validate())
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware.NewGatewayMiddleware(config).Handle())
+	auth := func(c *gin.Context) {
+		c.Set("user_role", c.GetHeader("X-Role"))
+		c.Next()
+	}
+	router.GET("/api/v1/users/:id", auth, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+			"id":       42,
+			"username": "alice",
+			"email":    "alice@example.com",
+			"profile":  gin.H{"phone": "13800000000", "score": 1.5},
+			"tags":     []gin.H{{"email": "tag@example.com"}},
+		}})
+	})
+	router.POST("/api/v1/echo", func(c *gin.Context) {
+		var body map[string]interface{}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"success": true, "data": body, "headers": gin.H{
+			"internal": c.GetHeader("X-Internal-Token"),
+			"gateway":  c.GetHeader("X-Gateway"),
+		}})
+	})
+	router.GET("/api/v1/stream", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		c.String(http.StatusOK, "data: {\"email\":\"a@b.c\"}\n\n")
+	})
+	return router
+}
+
+func TestGatewayConfigValidate(t *testing.T) {
+	for name, config := range map[string]Config.GatewayConfig{
+		"header op":    {Enabled: true, RequestHeaders: "/api/v1=X-Foo"},
+		"header scope": {Enabled: true, RequestHeaders: "api=-X-Foo"},
+		"redact role":  {Enabled: true, RedactFields: "=email"},
+		"redact field": {Enabled: true, RedactFields: "user="},
+		"body size":    {Enabled: true, MaxBodySize: "ten"},
+		"body limit":   {Enabled: true, BodyLimits: "/api/v1=-1"},
+	} {
+		assert.Error(t, config.Validate(), name)
+	}
+	assert.NoError(t, (&Config.GatewayConfig{RequestHeaders: "invalid"}).Validate(), "未启用时不检查")
+
+	size, err := Config.ParseByteSize("16KB")
+	require.NoError(t, err)
+	assert.Equal(t, int64(16<<10), size)
+}
+
+func TestGatewayRedactsFieldsByRole(t *testing.T) {
+	router := newGatewayRouter(t, &Config.GatewayConfig{RedactFields: "user=email|phone,*@/api/v1/users=username,admin@/api/v1/admin=email"})
+
+	get := func(role string) map[string]interface{} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/42", nil)
+		req.Header.Set("X-Role", role)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body["data"].(map[string]interface{})
+	}
+
+	data := get("user")
+	assert.Equal(t, Config.GatewayRedactedValue, data["email"])
+	assert.Equal(t, Config.GatewayRedactedValue, data["username"])
+	assert.Equal(t, Config.GatewayRedactedValue, data["profile"].(map[string]interface{})["phone"])
+	assert.Equal(t, Config.GatewayRedactedValue, data["tags"].([]interface{})[0].(map[string]interface{})["email"])
+	assert.Equal(t, float64(42), data["id"])
+
+	data = get("admin")
+	assert.Equal(t, "alice@example.com", data["email"])
+	assert.Equal(t, Config.GatewayRedactedValue, data["username"])
+}
+
+func TestGatewayRequestHeadersAndBodyLimits(t *testing.T) {
+	router := newGatewayRouter(t, &Config.GatewayConfig{
+		RequestHeaders: "/api/v1=-X-Internal-Token|+X-Gateway:cloud-platform",
+		MaxBodySize:    "1KB",
+		BodyLimits:     "POST /api/v1/echo=32B",
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/echo", strings.NewReader(`{"a":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Internal-Token", "forged")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	headers := body["headers"].(map[string]interface{})
+	assert.Equal(t, "", headers["internal"])
+	assert.Equal(t, "cloud-platform", headers["gateway"])
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/echo", strings.NewReader(`{"a":"`+strings.Repeat("x", 64)+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	// 未知长度的请求体读取超过限制时失败
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/echo", io.MultiReader(strings.NewReader(`{"a":"`+strings.Repeat("x", 64)+`"}`)))
+	req.ContentLength = -1
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGatewayMsgpackNegotiation(t *testing.T) {
+	router := newGatewayRouter(t, &Config.GatewayConfig{MsgpackEnabled: true})
+	var handle codec.MsgpackHandle
+
+	// 默认返回JSON
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/users/42", nil))
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+	assert.Contains(t, w.Header().Get("Vary"), "Accept")
+
+	// MessagePack 请求体转换为JSON，响应按 Accept 返回 MessagePack
+	var payload bytes.Buffer
+	require.NoError(t, codec.NewEncoder(&payload, &handle).Encode(map[string]interface{}{"name": "widget", "count": 3}))
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/echo", &payload)
+	req.Header.Set("Content-Type", "application/msgpack")
+	req.Header.Set("Accept", "application/msgpack")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/msgpack")
+
+	var decoded map[string]interface{}
+	handle.RawToString = true
+	require.NoError(t, codec.NewDecoderBytes(w.Body.Bytes(), &handle).Decode(&decoded))
+	assert.Equal(t, true, decoded["success"])
+	data := decoded["data"].(map[interface{}]interface{})
+	assert.Equal(t, "widget", data["name"])
+	assert.EqualValues(t, 3, data["count"])
+
+	// 非JSON响应不转换
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/stream", nil)
+	req.Header.Set("Accept", "application/msgpack")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "a@b.c")
+}
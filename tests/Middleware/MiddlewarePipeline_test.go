@@ -0,0 +1,147 @@
+package Middleware
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Middleware"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// defaultPipelineConfig 默认执行顺序的管道配置
+func defaultPipelineConfig() *Config.MiddlewarePipelineConfig {
+	return &Config.MiddlewarePipelineConfig{Order: strings.Join(Config.MiddlewareNames, ",")}
+}
+
+// tracePipeline 注册记录执行顺序的中间件，trace 在每个请求中按执行顺序追加名称
+func tracePipeline(config *Config.MiddlewarePipelineConfig, environment string, names ...string) (*Middleware.MiddlewarePipeline, *[]string) {
+	var trace []string
+	pipeline := Middleware.NewMiddlewarePipeline(config, environment)
+	for _, name := range names {
+		name := name
+		pipeline.Register(name, func(c *gin.Context) {
+			trace = append(trace, name)
+			c.Next()
+		})
+	}
+	return pipeline, &trace
+}
+
+// servePipeline 使用管道组装的中间件处理请求
+func servePipeline(t *testing.T, pipeline *Middleware.MiddlewarePipeline, method, path string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	handlers, _, err := pipeline.Build()
+	require.NoError(t, err)
+	router := gin.New()
+	router.Use(handlers...)
+	router.Any("/api/v1/users/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.Any("/api/v1/admin/logs", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.Any("/api/v1/admin/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, path, nil))
+}
+
+func TestMiddlewarePipelineConfigValidate(t *testing.T) {
+	assert.NoError(t, defaultPipelineConfig().Validate())
+
+	cases := map[string]*Config.MiddlewarePipelineConfig{
+		"unknown":            {Order: "recovery,cors,unknown"},
+		"duplicate":          {Order: strings.Join(append(Config.MiddlewareNames, "cors"), ",")},
+		"recovery not first": {Order: "cors,recovery", Disabled: strings.Join(Config.MiddlewareNames[2:], ",")},
+		"missing":            {Order: "recovery,cors"},
+		"disable recovery":   {Order: strings.Join(Config.MiddlewareNames, ","), Disabled: "recovery"},
+		"bad environment":    {Order: strings.Join(Config.MiddlewareNames, ","), Environments: "fault_injection"},
+		"bad scope":          {Order: strings.Join(Config.MiddlewareNames, ","), Scopes: "sql_log=api/v1"},
+		"scoped recovery":    {Order: strings.Join(Config.MiddlewareNames, ","), Scopes: "recovery=/api/v1"},
+	}
+	for name, config := range cases {
+		assert.Error(t, config.Validate(), name)
+	}
+
+	// 未列出的中间件显式禁用后配置有效
+	valid := &Config.MiddlewarePipelineConfig{Order: "recovery,cors", Disabled: strings.Join(Config.MiddlewareNames[2:], ",")}
+	assert.NoError(t, valid.Validate())
+}
+
+func TestMiddlewarePipelineOrderAndDisabled(t *testing.T) {
+	config := defaultPipelineConfig()
+	order := []string{"recovery", "request_log"}
+	for _, name := range Config.MiddlewareNames {
+		if name != "recovery" && name != "request_log" {
+			order = append(order, name)
+		}
+	}
+	config.Order = strings.Join(order, ",")
+	config.Disabled = "sql_log"
+	require.NoError(t, config.Validate())
+
+	pipeline, trace := tracePipeline(config, "production", "cors", "recovery", "request_log", "sql_log", "error_handling")
+	_, names, err := pipeline.Build()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"recovery", "request_log", "cors", "error_handling"}, names)
+
+	servePipeline(t, pipeline, http.MethodGet, "/api/v1/users/1")
+	assert.Equal(t, []string{"recovery", "request_log", "cors", "error_handling"}, *trace)
+}
+
+func TestMiddlewarePipelineEnvironments(t *testing.T) {
+	config := defaultPipelineConfig()
+	config.Environments = "fault_injection=staging|development,sql_log=!production"
+	require.NoError(t, config.Validate())
+
+	for environment, expected := range map[string][]string{
+		"staging":    {"recovery", "sql_log", "fault_injection"},
+		"Production": {"recovery"},
+		"test":       {"recovery", "sql_log"},
+	} {
+		pipeline, _ := tracePipeline(config, environment, "recovery", "sql_log", "fault_injection")
+		_, names, err := pipeline.Build()
+		require.NoError(t, err)
+		assert.Equal(t, expected, names, environment)
+	}
+}
+
+func TestMiddlewarePipelineScopes(t *testing.T) {
+	config := defaultPipelineConfig()
+	config.Scopes = "sql_log=/api/v1/admin|!/api/v1/admin/logs,rate_limit=POST /api/v1/users/:id,cors=!/api/v1/admin"
+	require.NoError(t, config.Validate())
+
+	pipeline, trace := tracePipeline(config, "", "recovery", "cors", "rate_limit", "sql_log")
+	_, names, err := pipeline.Build()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"recovery", "cors@scoped", "rate_limit@scoped", "sql_log@scoped"}, names)
+
+	cases := []struct {
+		method   string
+		path     string
+		expected []string
+	}{
+		{http.MethodGet, "/api/v1/users/1", []string{"recovery", "cors"}},
+		{http.MethodPost, "/api/v1/users/1", []string{"recovery", "cors", "rate_limit"}},
+		{http.MethodGet, "/api/v1/admin/users", []string{"recovery", "sql_log"}},
+		{http.MethodGet, "/api/v1/admin/logs", []string{"recovery"}},
+		{http.MethodGet, "/api/v1/administrators", []string{"recovery", "cors"}},
+	}
+	for _, tc := range cases {
+		*trace = nil
+		servePipeline(t, pipeline, tc.method, tc.path)
+		assert.Equal(t, tc.expected, *trace, tc.method+" "+tc.path)
+	}
+}
+
+func TestMiddlewarePipelineSkipsUnregistered(t *testing.T) {
+	pipeline, _ := tracePipeline(nil, "", "recovery", "cors")
+	pipeline.Register(Config.MiddlewareFaultInjection, nil)
+	_, names, err := pipeline.Build()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"recovery", "cors"}, names)
+
+	pipeline.Register("unknown", func(c *gin.Context) {})
+	_, _, err = pipeline.Build()
+	assert.Error(t, err)
+}
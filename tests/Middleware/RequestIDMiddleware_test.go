@@ -0,0 +1,122 @@
+package Middleware
+
+import (
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Utils"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newRequestIDRouter 创建使用请求ID中间件的路由，处理器返回 gin.Context 和请求上下文中的ID
+func newRequestIDRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware.NewRequestIDMiddleware().Handle())
+	router.GET("/ids", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"request_id":         c.GetString(Utils.RequestIDKey),
+			"correlation_id":     c.GetString(Utils.CorrelationIDKey),
+			"ctx_request_id":     Utils.RequestIDFromContext(c.Request.Context()),
+			"ctx_correlation_id": Utils.CorrelationIDFromContext(c.Request.Context()),
+		})
+	})
+	return router
+}
+
+func TestRequestIDMiddlewareGeneratesIDs(t *testing.T) {
+	router := newRequestIDRouter()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ids", nil))
+
+	requestID := w.Header().Get(Utils.RequestIDHeader)
+	require.NotEmpty(t, requestID)
+	assert.Equal(t, requestID, w.Header().Get(Utils.CorrelationIDHeader), "未传入关联ID时使用请求ID")
+	assert.JSONEq(t, `{"request_id":"`+requestID+`","correlation_id":"`+requestID+`",`+
+		`"ctx_request_id":"`+requestID+`","ctx_correlation_id":"`+requestID+`"}`, w.Body.String())
+}
+
+func TestRequestIDMiddlewareAcceptsIncomingIDs(t *testing.T) {
+	router := newRequestIDRouter()
+	req := httptest.NewRequest(http.MethodGet, "/ids", nil)
+	req.Header.Set(Utils.RequestIDHeader, "gateway-req.1")
+	req.Header.Set(Utils.CorrelationIDHeader, "trace:abc-123")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, "gateway-req.1", w.Header().Get(Utils.RequestIDHeader))
+	assert.Equal(t, "trace:abc-123", w.Header().Get(Utils.CorrelationIDHeader))
+	assert.JSONEq(t, `{"request_id":"gateway-req.1","correlation_id":"trace:abc-123",`+
+		`"ctx_request_id":"gateway-req.1","ctx_correlation_id":"trace:abc-123"}`, w.Body.String())
+}
+
+func TestRequestIDMiddlewareReplacesInvalidIDs(t *testing.T) {
+	router := newRequestIDRouter()
+	for _, invalid := range []string{"bad id", "id\r\nX-Injected: 1", "<script>", strings.Repeat("a", 129)} {
+		req := httptest.NewRequest(http.MethodGet, "/ids", nil)
+		req.Header[Utils.RequestIDHeader] = []string{invalid}
+		req.Header[Utils.CorrelationIDHeader] = []string{invalid}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		requestID := w.Header().Get(Utils.RequestIDHeader)
+		assert.NotEqual(t, invalid, requestID)
+		assert.True(t, Utils.ValidRequestID(requestID))
+		assert.Equal(t, requestID, w.Header().Get(Utils.CorrelationIDHeader), "无效的关联ID使用请求ID")
+		assert.Empty(t, w.Header().Get("X-Injected"))
+	}
+}
+
+func TestRecordsCarryRequestIDsFromContext(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "request_id.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.AuditLog{}, &Models.SecurityEvent{}))
+
+	router := newRequestIDRouter()
+	router.POST("/records", func(c *gin.Context) {
+		tx := db.WithContext(c.Request.Context())
+		require.NoError(t, tx.Create(Models.NewAuditLog(1, "admin", "update", "user", 2)).Error)
+		// 显式设置的ID不被覆盖
+		require.NoError(t, tx.Create(Models.NewAuditLog(1, "admin", "delete", "user", 3).SetRequestID("job-1")).Error)
+		require.NoError(t, tx.Create(&Models.SecurityEvent{EventType: "login_failed", EventLevel: "high"}).Error)
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/records", nil)
+	req.Header.Set(Utils.RequestIDHeader, "req-42")
+	req.Header.Set(Utils.CorrelationIDHeader, "corr-7")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	var audits []Models.AuditLog
+	require.NoError(t, db.Order("id").Find(&audits).Error)
+	require.Len(t, audits, 2)
+	assert.Equal(t, "req-42", audits[0].RequestID)
+	assert.Equal(t, "corr-7", audits[0].CorrelationID)
+	assert.Equal(t, "job-1", audits[1].RequestID)
+	assert.Equal(t, "corr-7", audits[1].CorrelationID)
+
+	var event Models.SecurityEvent
+	require.NoError(t, db.Where("correlation_id = ?", "corr-7").First(&event).Error)
+	assert.Equal(t, "req-42", event.RequestID)
+
+	// 没有请求上下文时不设置
+	require.NoError(t, db.Create(Models.NewAuditLog(1, "system", "cleanup", "logs", 0)).Error)
+	var background Models.AuditLog
+	require.NoError(t, db.Where("action = ?", "cleanup").First(&background).Error)
+	assert.Empty(t, background.RequestID)
+	assert.Empty(t, background.CorrelationID)
+}
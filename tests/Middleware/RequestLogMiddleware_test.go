@@ -0,0 +1,108 @@
+package Middleware
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Services"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLogBodyCapture(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	config := &Config.LogConfig{}
+	config.SetDefaults()
+	config.BasePath = t.TempDir()
+	config.RequestLog.IncludeBody = true
+	config.RequestLog.MaxBodySize = 1 // 1KB
+	config.RequestLog.SkipBodyPaths = []string{"/private"}
+	logManager := Services.NewLogManagerService(config)
+	defer logManager.Close()
+
+	router := gin.New()
+	router.Use(Middleware.NewRequestLogMiddleware(logManager).RequestLog())
+	echo := func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+	}
+	router.POST("/echo", echo)
+	router.POST("/private", echo)
+	router.POST("/opt-out", Middleware.SkipBodyLogging(), echo)
+	router.POST("/text", func(c *gin.Context) { c.String(http.StatusOK, "plain") })
+
+	send := func(path, contentType, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(w, req)
+		return w
+	}
+	lastEntry := func(path string) Services.LogEntry {
+		var entry Services.LogEntry
+		require.Eventually(t, func() bool {
+			result, err := Services.NewLogQueryService(config).Search(context.Background(), Services.LogQuery{
+				Loggers: []string{"request"},
+				Text:    `"path":"` + path + `"`,
+			})
+			require.NoError(t, err)
+			if len(result.Entries) == 0 {
+				return false
+			}
+			entry = result.Entries[0]
+			return true
+		}, 2*time.Second, 10*time.Millisecond)
+		return entry
+	}
+
+	t.Run("JSON记录为结构化字段并脱敏", func(t *testing.T) {
+		w := send("/echo", "application/json", `{"name":"bob","password":"hunter2","id":12345678901234567}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		entry := lastEntry("/echo")
+		requestBody, ok := entry.Fields["request_body"].(map[string]interface{})
+		require.True(t, ok, "request_body 应该是结构化字段")
+		assert.Equal(t, "bob", requestBody["name"])
+		assert.Equal(t, "***MASKED***", requestBody["password"])
+		assert.Equal(t, 12345678901234567.0, requestBody["id"])
+		assert.IsType(t, map[string]interface{}{}, entry.Fields["response_body"])
+	})
+
+	t.Run("超出大小截断且不影响处理器读取", func(t *testing.T) {
+		long := `{"data":"` + strings.Repeat("x", 2000) + `"}`
+		w := send("/echo?long=1", "application/json", long)
+		assert.Equal(t, long, w.Body.String())
+
+		entry := lastEntry("/echo")
+		assert.Equal(t, true, entry.Fields["request_body_truncated"])
+		assert.Equal(t, true, entry.Fields["response_body_truncated"])
+		assert.Len(t, entry.Fields["request_body"], 1024)
+	})
+
+	t.Run("路径排除和路由级退出", func(t *testing.T) {
+		send("/private", "application/json", `{"a":1}`)
+		send("/opt-out", "application/json", `{"a":1}`)
+
+		for _, path := range []string{"/private", "/opt-out"} {
+			entry := lastEntry(path)
+			assert.NotContains(t, entry.Fields, "request_body", path)
+			assert.NotContains(t, entry.Fields, "response_body", path)
+		}
+	})
+
+	t.Run("非JSON内容类型不记录", func(t *testing.T) {
+		send("/text", "text/plain", "hello")
+		entry := lastEntry("/text")
+		assert.NotContains(t, entry.Fields, "request_body")
+		assert.NotContains(t, entry.Fields, "response_body")
+	})
+}
@@ -0,0 +1,233 @@
+package Middleware
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Services"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resilienceConfig() *Config.ResilienceConfig {
+	config := &Config.ResilienceConfig{}
+	config.SetDefaults()
+	config.Bulkhead.MaxWait = 0
+	config.CircuitBreaker.FailureThreshold = 2
+	config.CircuitBreaker.SuccessThreshold = 2
+	config.CircuitBreaker.OpenTimeout = 50 * time.Millisecond
+	config.CircuitBreaker.RequiredDependencies = []string{"database"}
+	return config
+}
+
+func serve(router *gin.Engine, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestResilienceDependencyBreaker(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var healthy atomic.Bool
+	resilience := Middleware.NewResilienceMiddleware(resilienceConfig())
+	resilience.RegisterDependency("database", func(ctx context.Context) error {
+		if healthy.Load() {
+			return nil
+		}
+		return errors.New("connection refused")
+	})
+	// 非必需依赖熔断时不拒绝请求
+	resilience.RegisterDependency("redis", func(ctx context.Context) error {
+		return errors.New("timeout")
+	})
+
+	var alerts []Services.MonitoringAlert
+	resilience.OnAlert(func(alert Services.MonitoringAlert) {
+		alerts = append(alerts, alert)
+		// 回调中读取状态不应死锁
+		resilience.Stats()
+	})
+
+	router := gin.New()
+	router.Use(resilience.Handle())
+	router.GET("/api/v1/posts", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	router.GET("/health", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	resilience.CheckDependencies(context.Background())
+	assert.Equal(t, http.StatusOK, serve(router, "/api/v1/posts").Code)
+
+	resilience.CheckDependencies(context.Background())
+	w := serve(router, "/api/v1/posts")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "DEPENDENCY_UNAVAILABLE")
+	assert.Equal(t, http.StatusOK, serve(router, "/health").Code)
+	require.Len(t, alerts, 2)
+	assert.Equal(t, Middleware.ResilienceAlertDependencyOpen, alerts[0].Type)
+	assert.Equal(t, Middleware.ResilienceAlertDependencyOpen, alerts[1].Type)
+
+	// 等待时间内不检查，结束后半开放行请求；一次失败即重新熔断
+	resilience.CheckDependencies(context.Background())
+	assert.Len(t, alerts, 2)
+	time.Sleep(60 * time.Millisecond)
+	resilience.CheckDependencies(context.Background())
+	assert.Equal(t, http.StatusServiceUnavailable, serve(router, "/api/v1/posts").Code)
+
+	// 依赖恢复后半开状态下连续成功才关闭
+	healthy.Store(true)
+	time.Sleep(60 * time.Millisecond)
+	resilience.CheckDependencies(context.Background())
+	assert.Equal(t, http.StatusOK, serve(router, "/api/v1/posts").Code)
+	resilience.CheckDependencies(context.Background())
+
+	stats := resilience.Stats()
+	require.Len(t, stats.Dependencies, 2)
+	database, redis := stats.Dependencies[0], stats.Dependencies[1]
+	assert.Equal(t, "database", database.Name)
+	assert.True(t, database.Required)
+	assert.Equal(t, Middleware.DependencyStateClosed, database.State)
+	assert.Equal(t, int64(2), database.Rejected)
+	assert.Equal(t, int64(5), database.StateChanges)
+	assert.Empty(t, database.LastError)
+	assert.False(t, redis.Required)
+	assert.Equal(t, Middleware.DependencyStateOpen, redis.State)
+	assert.Equal(t, "timeout", redis.LastError)
+
+	last := alerts[len(alerts)-1]
+	assert.Equal(t, Middleware.ResilienceAlertDependencyRecovered, last.Type)
+	assert.Equal(t, "database", last.Metadata["dependency"])
+}
+
+func TestResilienceBulkhead(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	config := resilienceConfig()
+	config.Bulkhead.DefaultLimit = 0
+	config.Bulkhead.RouteLimits = "GET /slow/:id=1"
+	resilience := Middleware.NewResilienceMiddleware(config)
+
+	release := make(chan struct{})
+	entered := make(chan struct{})
+	router := gin.New()
+	router.Use(resilience.Handle())
+	router.GET("/slow/:id", func(c *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		c.String(http.StatusOK, "ok")
+	})
+	router.GET("/fast", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		assert.Equal(t, http.StatusOK, serve(router, "/slow/1").Code)
+	}()
+	<-entered
+
+	// 同一路由模板共享名额，其他路由不受影响
+	w := serve(router, "/slow/2")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "BULKHEAD_FULL")
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusOK, serve(router, "/fast").Code)
+
+	stats := resilience.Stats()
+	require.Len(t, stats.Bulkheads, 1)
+	assert.Equal(t, "GET /slow/:id", stats.Bulkheads[0].Route)
+	assert.Equal(t, 1, stats.Bulkheads[0].InFlight)
+	assert.Equal(t, int64(1), stats.Bulkheads[0].Rejected)
+
+	close(release)
+	wg.Wait()
+
+	// 名额释放后等待中的请求可以获取
+	config.Bulkhead.MaxWait = time.Second
+	go func() {
+		<-entered
+	}()
+	assert.Equal(t, http.StatusOK, serve(router, "/slow/3").Code)
+	assert.Equal(t, 0, resilience.Stats().Bulkheads[0].InFlight)
+}
+
+func TestResilienceBulkheadSkipsLongLivedConnections(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	config := resilienceConfig()
+	config.Bulkhead.DefaultLimit = 1
+	resilience := Middleware.NewResilienceMiddleware(config)
+
+	release := make(chan struct{})
+	entered := make(chan struct{}, 3)
+	router := gin.New()
+	router.Use(resilience.Handle())
+	hold := func(c *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		c.String(http.StatusOK, "ok")
+	}
+	router.GET("/api/v1/monitoring/stream", hold)
+	router.GET("/events", hold)
+	router.GET("/ws", hold)
+
+	request := func(path, header, value string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	var wg sync.WaitGroup
+	for _, item := range [][3]string{
+		{"/api/v1/monitoring/stream", "", ""},
+		{"/api/v1/monitoring/stream", "", ""},
+		{"/events", "Accept", "text/event-stream"},
+		{"/events", "Accept", "text/event-stream"},
+		{"/ws", "Upgrade", "websocket"},
+		{"/ws", "Upgrade", "websocket"},
+	} {
+		wg.Add(1)
+		go func(path, header, value string) {
+			defer wg.Done()
+			assert.Equal(t, http.StatusOK, request(path, header, value).Code)
+		}(item[0], item[1], item[2])
+		<-entered
+	}
+
+	// 默认配置中的事件流路由不限制并发，事件流和WebSocket升级请求不占用名额
+	for _, stats := range resilience.Stats().Bulkheads {
+		assert.NotEqual(t, "GET /api/v1/monitoring/stream", stats.Route)
+	}
+	close(release)
+	wg.Wait()
+}
+
+func TestResilienceConfigRouteLimits(t *testing.T) {
+	config := resilienceConfig()
+	config.Bulkhead.RouteLimits = "GET  /api/v1/posts=10, /api/v1/search=2,"
+	limits, err := config.ParseRouteLimits()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"GET /api/v1/posts": 10, "/api/v1/search": 2}, limits)
+	assert.NoError(t, config.Validate())
+
+	config.Bulkhead.RouteLimits = "GET /api/v1/posts=many"
+	assert.Error(t, config.Validate())
+
+	config.Bulkhead.RouteLimits = ""
+	config.CircuitBreaker.FailureThreshold = 0
+	assert.Error(t, config.Validate())
+}
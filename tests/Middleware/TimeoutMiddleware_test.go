@@ -0,0 +1,161 @@
+package Middleware
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Models"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTimeoutRouter 创建使用分组超时配置的路由
+func newTimeoutRouter(config *Config.RequestTimeoutConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware.NewTimeoutMiddleware(nil).HandleGroups(config))
+	return router
+}
+
+func TestTimeoutMiddlewareReplacesLateResponse(t *testing.T) {
+	router := newTimeoutRouter(&Config.RequestTimeoutConfig{Enabled: true, Default: 50 * time.Millisecond})
+	router.GET("/slow", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		c.Header("Content-Type", "text/csv")
+		c.String(http.StatusInternalServerError, c.Request.Context().Err().Error())
+	})
+	router.GET("/fast", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	assert.Equal(t, http.StatusRequestTimeout, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, false, body["success"])
+	assert.Equal(t, "Request timeout", body["message"])
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestTimeoutMiddlewareGroups(t *testing.T) {
+	config := &Config.RequestTimeoutConfig{
+		Enabled: true,
+		Default: time.Minute,
+		Groups:  "/api/v1/auth=5s, /api/v1/stream=0, /api/v1/auth/export=10m",
+	}
+	router := newTimeoutRouter(config)
+	deadline := func(c *gin.Context) {
+		if deadline, ok := c.Request.Context().Deadline(); ok {
+			c.String(http.StatusOK, time.Until(deadline).Round(time.Second).String())
+			return
+		}
+		c.String(http.StatusOK, "none")
+	}
+	for _, path := range []string{"/api/v1/auth", "/api/v1/auth/login", "/api/v1/authors", "/api/v1/auth/export/users", "/api/v1/stream"} {
+		router.GET(path, deadline)
+	}
+
+	cases := map[string]string{
+		"/api/v1/auth":              "5s",
+		"/api/v1/auth/login":        "5s",
+		"/api/v1/authors":           "1m0s", // 只按路径段匹配前缀
+		"/api/v1/auth/export/users": "10m0s",
+		"/api/v1/stream":            "none",
+	}
+	for path, expected := range cases {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, expected, w.Body.String(), path)
+	}
+
+	disabled := newTimeoutRouter(&Config.RequestTimeoutConfig{Enabled: false, Default: time.Second})
+	disabled.GET("/api/v1/auth", deadline)
+	w := httptest.NewRecorder()
+	disabled.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/auth", nil))
+	assert.Equal(t, "none", w.Body.String())
+}
+
+func TestTimeoutMiddlewareDefaultGroupsSkipLongLivedRoutes(t *testing.T) {
+	(&Config.RequestTimeoutConfig{}).SetDefaults()
+	config := &Config.RequestTimeoutConfig{Enabled: true, Default: time.Minute, Groups: viper.GetString("request_timeout.groups")}
+	require.NoError(t, config.Validate())
+	router := newTimeoutRouter(config)
+	router.GET("/*path", func(c *gin.Context) {
+		_, ok := c.Request.Context().Deadline()
+		c.String(http.StatusOK, "%t", ok)
+	})
+
+	// 事件流、日志实时查看和WebSocket不设置截止时间
+	cases := map[string]string{
+		"/api/v1/monitoring/stream":    "false",
+		"/api/v1/notifications/stream": "false",
+		"/api/v1/logs/tail/app":        "false",
+		"/api/v1/ws/notifications":     "false",
+		"/api/v1/logs":                 "true",
+	}
+	for path, expected := range cases {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, expected, w.Body.String(), path)
+	}
+}
+
+func TestTimeoutMiddlewareCancelsDatabaseQuery(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "timeout.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.LoginAttempt{}))
+
+	var queryErr error
+	router := newTimeoutRouter(&Config.RequestTimeoutConfig{Enabled: true, Default: 20 * time.Millisecond})
+	router.GET("/report", func(c *gin.Context) {
+		time.Sleep(40 * time.Millisecond)
+		var count int64
+		queryErr = db.WithContext(c.Request.Context()).Model(&Models.LoginAttempt{}).Count(&count).Error
+		if queryErr != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": queryErr.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/report", nil))
+	assert.True(t, errors.Is(queryErr, context.DeadlineExceeded), "查询应随请求上下文取消: %v", queryErr)
+	assert.Equal(t, http.StatusRequestTimeout, w.Code)
+}
+
+func TestRequestTimeoutConfigValidate(t *testing.T) {
+	config := &Config.RequestTimeoutConfig{Enabled: true, Default: time.Second, Groups: "/api/v1/exports=5m,/api/v1/ws=0"}
+	require.NoError(t, config.Validate())
+	groups, err := config.ParseGroups()
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	assert.Equal(t, "/api/v1/exports", groups[0].Prefix)
+
+	for _, invalid := range []string{"/api/v1/exports", "api/v1=5s", "/api/v1=soon", "/api/v1=-1s"} {
+		config.Groups = invalid
+		assert.Error(t, config.Validate(), invalid)
+	}
+	config.Enabled = false
+	assert.NoError(t, config.Validate())
+}
@@ -0,0 +1,207 @@
+package Migrations
+
+import (
+	"cloud-platform-api/app/Database/Migrations"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type widget struct {
+	ID   uint `gorm:"primarykey"`
+	Name string
+	SKU  string
+}
+
+// testMigration 测试用迁移
+type testMigration struct {
+	name string
+	up   func(db *gorm.DB) error
+}
+
+func (m *testMigration) Up(db *gorm.DB) error   { return m.up(db) }
+func (m *testMigration) Down(db *gorm.DB) error { return nil }
+func (m *testMigration) GetName() string        { return m.name }
+
+// setupWidgets 创建包含 rows 行数据的 widgets 表
+func setupWidgets(t *testing.T, rows int) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "preflight.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&widget{}))
+	for i := 0; i < rows; i++ {
+		require.NoError(t, db.Create(&widget{Name: "widget"}).Error)
+	}
+	return db
+}
+
+func preflightOptions() Migrations.PreflightOptions {
+	return Migrations.PreflightOptions{
+		LargeTableRows: 1000,
+		RowsPerSecond:  10,
+		MaxLockTime:    time.Second,
+		SafeMode:       true,
+		ApprovalTTL:    time.Hour,
+	}
+}
+
+var (
+	addIndex = &testMigration{name: "add_widget_sku_index", up: func(db *gorm.DB) error {
+		return db.Exec("CREATE INDEX idx_widgets_sku ON widgets (sku)").Error
+	}}
+	dropColumn = &testMigration{name: "drop_widget_name", up: func(db *gorm.DB) error {
+		return db.Migrator().DropColumn(&widget{}, "Name")
+	}}
+)
+
+func TestPlanRecordsStatementsWithoutExecuting(t *testing.T) {
+	db := setupWidgets(t, 30)
+	manager := Migrations.NewMigrationManager(db)
+	manager.SetMigrations([]Migrations.MigrationInterface{addIndex, dropColumn})
+
+	plan, err := manager.Plan(preflightOptions())
+	require.NoError(t, err)
+	assert.Equal(t, "sqlite", plan.Driver)
+	assert.Len(t, plan.Hash, 64)
+	require.Len(t, plan.Migrations, 2)
+
+	index := plan.Migrations[0].Statements
+	require.Len(t, index, 1)
+	assert.Equal(t, Migrations.StatementCreateIndex, index[0].Kind)
+	assert.Equal(t, "widgets", index[0].Table)
+	assert.Equal(t, int64(30), index[0].Rows)
+	assert.Equal(t, "3s", index[0].EstimatedLock)
+	assert.True(t, index[0].HighRisk, "预计锁表时间超过最长锁表时间")
+	assert.False(t, index[0].Online, "SQLite 不支持在线创建索引")
+
+	assert.True(t, plan.Destructive)
+	assert.True(t, plan.HighRisk)
+	assert.NotEmpty(t, plan.DestructiveStatements())
+	assert.Contains(t, plan.DestructiveStatements()[0], "drop_widget_name")
+
+	// 计划不修改数据库
+	assert.False(t, db.Migrator().HasIndex(&widget{}, "idx_widgets_sku"))
+	assert.True(t, db.Migrator().HasColumn(&widget{}, "Name"))
+
+	again, err := manager.Plan(preflightOptions())
+	require.NoError(t, err)
+	assert.Equal(t, plan.Hash, again.Hash, "相同的待执行迁移生成相同的哈希")
+}
+
+func TestSafeModeBlocksDestructiveChanges(t *testing.T) {
+	db := setupWidgets(t, 0)
+	manager := Migrations.NewMigrationManager(db)
+	manager.SetMigrations([]Migrations.MigrationInterface{dropColumn})
+
+	err := manager.RunMigrationsWithPreflight(preflightOptions())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--allow-destructive")
+	assert.True(t, db.Migrator().HasColumn(&widget{}, "Name"))
+
+	options := preflightOptions()
+	options.AllowDestructive = true
+	require.NoError(t, manager.RunMigrationsWithPreflight(options))
+	assert.False(t, db.Migrator().HasColumn(&widget{}, "Name"))
+}
+
+func TestApprovalRequiredBeforeMigrating(t *testing.T) {
+	db := setupWidgets(t, 0)
+	manager := Migrations.NewMigrationManager(db)
+	manager.SetMigrations([]Migrations.MigrationInterface{addIndex})
+	options := preflightOptions()
+	options.RequireApproval = true
+
+	err := manager.RunMigrationsWithPreflight(options)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "未审批")
+
+	plan, err := manager.Plan(options)
+	require.NoError(t, err)
+	_, err = manager.ApprovePlan("stale-hash", "ops", "", options)
+	assert.Error(t, err, "哈希与当前计划不一致")
+	_, err = manager.ApprovePlan(plan.Hash, "", "", options)
+	assert.Error(t, err, "审批人不能为空")
+
+	approval, err := manager.ApprovePlan(plan.Hash, "ops", "变更单 42", options)
+	require.NoError(t, err)
+	assert.Equal(t, "add_widget_sku_index", approval.Migrations)
+
+	require.NoError(t, manager.RunMigrationsWithPreflight(options))
+	assert.True(t, db.Migrator().HasIndex(&widget{}, "idx_widgets_sku"))
+
+	var used Migrations.MigrationPlanApproval
+	require.NoError(t, db.First(&used, approval.ID).Error)
+	assert.NotNil(t, used.UsedAt, "审批只能使用一次")
+
+	// 新增迁移后计划哈希变化，需要重新审批
+	manager.SetMigrations([]Migrations.MigrationInterface{addIndex, dropColumn})
+	options.AllowDestructive = true
+	err = manager.RunMigrationsWithPreflight(options)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "未审批")
+}
+
+func TestExpiredApprovalRejected(t *testing.T) {
+	db := setupWidgets(t, 0)
+	manager := Migrations.NewMigrationManager(db)
+	manager.SetMigrations([]Migrations.MigrationInterface{addIndex})
+	options := preflightOptions()
+	options.RequireApproval = true
+
+	plan, err := manager.Plan(options)
+	require.NoError(t, err)
+	approval, err := manager.ApprovePlan(plan.Hash, "ops", "", options)
+	require.NoError(t, err)
+	require.NoError(t, db.Model(approval).Update("approved_at", time.Now().Add(-2*time.Hour)).Error)
+
+	err = manager.RunMigrationsWithPreflight(options)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "审批已失效")
+}
+
+func TestClassifyStatement(t *testing.T) {
+	for query, expected := range map[string]struct {
+		kind        string
+		table       string
+		destructive bool
+	}{
+		"CREATE TABLE `users` (`id` bigint)":                               {Migrations.StatementCreateTable, "users", false},
+		`DROP TABLE IF EXISTS "users" CASCADE`:                             {Migrations.StatementDropTable, "users", true},
+		"ALTER TABLE `users` ADD `nickname` varchar(50)":                   {Migrations.StatementAddColumn, "users", false},
+		`ALTER TABLE "users" DROP COLUMN "nickname"`:                       {Migrations.StatementDropColumn, "users", true},
+		`ALTER TABLE "users" ALTER COLUMN "age" TYPE bigint`:               {Migrations.StatementAlterColumn, "users", true},
+		"ALTER TABLE `users` MODIFY COLUMN `age` bigint":                   {Migrations.StatementAlterColumn, "users", true},
+		"ALTER TABLE `users` RENAME COLUMN `name` TO `username`":           {Migrations.StatementRename, "users", true},
+		`CREATE UNIQUE INDEX IF NOT EXISTS "idx_email" ON "users" (email)`: {Migrations.StatementCreateIndex, "users", false},
+		"ALTER TABLE `users` ADD INDEX `idx_age` (`age`)":                  {Migrations.StatementCreateIndex, "users", false},
+		"ALTER TABLE `users` DROP FOREIGN KEY `fk_team`":                   {Migrations.StatementDropConstraint, "users", false},
+		`DELETE FROM "sessions" WHERE expired = true`:                      {Migrations.StatementData, "sessions", true},
+		`UPDATE "users" SET status = 'active'`:                             {Migrations.StatementData, "users", false},
+	} {
+		statement := Migrations.ClassifyStatement(query)
+		assert.Equal(t, expected.kind, statement.Kind, query)
+		assert.Equal(t, expected.table, statement.Table, query)
+		assert.Equal(t, expected.destructive, statement.Destructive, query)
+	}
+
+	statement := Migrations.ClassifyStatement("ALTER TABLE `users` ADD `tenant_id` bigint NOT NULL")
+	assert.NotEmpty(t, statement.Notes, "非空列没有默认值时旧版本写入会失败")
+}
+
+func TestOnlineIndexSQL(t *testing.T) {
+	assert.Equal(t, `CREATE INDEX CONCURRENTLY IF NOT EXISTS "idx_email" ON "users" ("email")`,
+		Migrations.OnlineIndexSQL("postgres", `CREATE INDEX IF NOT EXISTS "idx_email" ON "users" ("email")`))
+	assert.Equal(t, `CREATE UNIQUE INDEX CONCURRENTLY "idx_email" ON "users" ("email")`,
+		Migrations.OnlineIndexSQL("postgres", `CREATE UNIQUE INDEX "idx_email" ON "users" ("email")`))
+	assert.Equal(t, "CREATE INDEX `idx_email` ON `users` (`email`) ALGORITHM=INPLACE LOCK=NONE",
+		Migrations.OnlineIndexSQL("mysql", "CREATE INDEX `idx_email` ON `users` (`email`)"))
+	assert.Equal(t, "CREATE INDEX idx ON users (email)", Migrations.OnlineIndexSQL("sqlite", "CREATE INDEX idx ON users (email)"))
+	assert.Equal(t, "DROP INDEX idx", Migrations.OnlineIndexSQL("postgres", "DROP INDEX idx"))
+}
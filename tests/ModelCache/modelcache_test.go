@@ -0,0 +1,247 @@
+package ModelCache
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func setupDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "cache.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.User{}, &Models.AlertRule{}))
+	return db
+}
+
+// setupCache 创建模型缓存并设为全局缓存
+func setupCache(t *testing.T, db *gorm.DB) *Services.ModelCache {
+	cache := Services.NewModelCache(nil)
+	require.NoError(t, cache.InstallGormCallbacks(db))
+	Services.SetDefaultModelCache(cache)
+	t.Cleanup(func() { Services.SetDefaultModelCache(nil) })
+	return cache
+}
+
+func tableStats(cache *Services.ModelCache, table string) Services.ModelCacheStats {
+	for _, s := range cache.Stats() {
+		if s.Table == table {
+			return s
+		}
+	}
+	return Services.ModelCacheStats{Table: table}
+}
+
+func TestRememberAndExpire(t *testing.T) {
+	cache := Services.NewModelCache(&Config.ModelCacheConfig{TTL: time.Minute, MaxEntries: 2})
+	now := time.Now()
+	cache.SetClock(func() time.Time { return now })
+
+	loads := 0
+	load := func() (string, error) {
+		loads++
+		return fmt.Sprintf("v%d", loads), nil
+	}
+	value, err := Services.RememberModel(cache, "items", "a", load)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", value)
+	value, _ = Services.RememberModel(cache, "items", "a", load)
+	assert.Equal(t, "v1", value)
+
+	now = now.Add(2 * time.Minute)
+	value, _ = Services.RememberModel(cache, "items", "a", load)
+	assert.Equal(t, "v2", value)
+
+	// 加载失败不缓存
+	_, err = Services.RememberModel(cache, "items", "b", func() (string, error) { return "", gorm.ErrRecordNotFound })
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	value, _ = Services.RememberModel(cache, "items", "b", load)
+	assert.Equal(t, "v3", value)
+
+	// 超过最大条目数时淘汰最早过期的条目
+	now = now.Add(time.Second)
+	Services.RememberModel(cache, "items", "c", load)
+	stats := tableStats(cache, "items")
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, int64(1), stats.Evictions)
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(1), stats.Errors)
+	assert.InDelta(t, 100.0/6, stats.HitRate, 0.01)
+
+	// 未启用缓存时直接加载
+	value, _ = Services.RememberModel[string](nil, "items", "a", load)
+	assert.Equal(t, "v5", value)
+}
+
+func TestConcurrentMissesLoadOnce(t *testing.T) {
+	cache := Services.NewModelCache(nil)
+	release := make(chan struct{})
+	var mu sync.Mutex
+	loads := 0
+	load := func() (int, error) {
+		mu.Lock()
+		loads++
+		mu.Unlock()
+		<-release
+		return 42, nil
+	}
+
+	const workers = 20
+	var wg sync.WaitGroup
+	results := make([]int, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = Services.RememberModel(cache, "users", "id:1", load)
+		}(i)
+	}
+	require.Eventually(t, func() bool {
+		return tableStats(cache, "users").Shared == workers-1
+	}, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, 1, loads)
+	for _, result := range results {
+		assert.Equal(t, 42, result)
+	}
+	assert.Equal(t, int64(1), tableStats(cache, "users").Loads)
+}
+
+func TestInvalidationDuringLoadDiscardsResult(t *testing.T) {
+	cache := Services.NewModelCache(nil)
+	started, release := make(chan struct{}), make(chan struct{})
+	go Services.RememberModel(cache, "users", "id:1", func() (string, error) {
+		close(started)
+		<-release
+		return "stale", nil
+	})
+	<-started
+	cache.Invalidate("users")
+	close(release)
+
+	require.Eventually(t, func() bool { return tableStats(cache, "users").Loads == 1 && tableStats(cache, "users").Entries == 0 }, time.Second, time.Millisecond)
+	value, _ := Services.RememberModel(cache, "users", "id:1", func() (string, error) { return "fresh", nil })
+	assert.Equal(t, "fresh", value)
+}
+
+func TestGormWritesInvalidate(t *testing.T) {
+	db := setupDB(t)
+	cache := setupCache(t, db)
+
+	_, err := Services.FindUserCached(db, 1)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	user := Models.User{Username: "cached", Email: "cached@example.com", Password: "x", Role: "user"}
+	require.NoError(t, db.Create(&user).Error)
+	found, err := Services.FindUserCached(db, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "user", found.Role)
+	found, _ = Services.FindUserCached(db, user.ID)
+	assert.Equal(t, int64(1), tableStats(cache, "users").Hits)
+
+	// 条件更新同样使缓存失效
+	require.NoError(t, db.Model(&Models.User{}).Where("id = ?", user.ID).Update("role", "admin").Error)
+	found, _ = Services.FindUserCached(db, user.ID)
+	assert.Equal(t, "admin", found.Role)
+
+	require.NoError(t, db.Exec("UPDATE users SET status = 0 WHERE id = ?", user.ID).Error)
+	found, _ = Services.FindUserCached(db, user.ID)
+	assert.False(t, found.IsActive())
+
+	// 其他表的写入不影响用户缓存
+	loads := tableStats(cache, "users").Loads
+	require.NoError(t, db.Create(&Models.AlertRule{Name: "cpu", Type: "threshold", MetricType: "system", MetricName: "cpu_usage", Condition: ">", Threshold: 90, Severity: "high", Enabled: true}).Error)
+	Services.FindUserCached(db, user.ID)
+	assert.Equal(t, loads, tableStats(cache, "users").Loads)
+
+	rules, err := Services.FindAlertRulesCached(db)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.NoError(t, db.Delete(&Models.AlertRule{}, rules[0].ID).Error)
+	rules, _ = Services.FindAlertRulesCached(db)
+	assert.Empty(t, rules)
+
+	require.NoError(t, db.Delete(&Models.User{}, user.ID).Error)
+	_, err = Services.FindUserCached(db, user.ID)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+}
+
+func TestCollectorReportsHitRate(t *testing.T) {
+	cache := Services.NewModelCache(nil)
+	collector := cache.Collector()
+	load := func() (int, error) { return 1, nil }
+	for i := 0; i < 4; i++ {
+		Services.RememberModel(cache, "users", "id:1", load)
+	}
+
+	values, err := collector.Collect(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 75.0, values["model_cache_hit_rate"])
+	assert.Equal(t, 75.0, values["model_cache_users_hit_rate"])
+	assert.Equal(t, 3.0, values["model_cache_users_hits_total"])
+	assert.Equal(t, 1.0, values["model_cache_entries"])
+
+	// 命中率按两次采集之间的读取计算，期间没有读取时不输出
+	values, _ = collector.Collect(context.Background())
+	assert.NotContains(t, values, "model_cache_hit_rate")
+	Services.RememberModel(cache, "users", "id:1", load)
+	values, _ = collector.Collect(context.Background())
+	assert.Equal(t, 100.0, values["model_cache_hit_rate"])
+}
+
+func TestRequireRoleUsesCurrentRole(t *testing.T) {
+	db := setupDB(t)
+	setupCache(t, db)
+	previous := Database.DB
+	Database.DB = db
+	t.Cleanup(func() { Database.DB = previous })
+
+	user := Models.User{Username: "demoted", Email: "demoted@example.com", Password: "x", Role: "admin"}
+	require.NoError(t, db.Create(&user).Error)
+
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("user_id", c.GetHeader("X-User"))
+		c.Set("user_role", "admin") // 令牌签发时的角色
+	})
+	permission := Middleware.NewPermissionMiddleware(Storage.NewStorageManager(&Config.StorageConfig{BasePath: t.TempDir()}))
+	engine.GET("/admin", permission.RequireRole("admin"), func(c *gin.Context) { c.Status(http.StatusOK) })
+	request := func(userID uint) int {
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		req.Header.Set("X-User", fmt.Sprint(userID))
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request(user.ID))
+	require.NoError(t, db.Model(&user).Update("role", "user").Error)
+	assert.Equal(t, http.StatusForbidden, request(user.ID))
+	assert.Equal(t, http.StatusUnauthorized, request(user.ID+100))
+}
@@ -0,0 +1,111 @@
+package Monitoring
+
+import (
+	"cloud-platform-api/app/Services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cpuRule cpu_usage 指标固定为75，通过调整阈值控制是否触发
+func cpuRule(t *testing.T, service *Services.AlertService) *Services.AlertRule {
+	rule := &Services.AlertRule{
+		ID:        "cpu_high",
+		Name:      "CPU使用率过高",
+		Metric:    "cpu_usage",
+		Condition: ">",
+		Threshold: 70,
+		Level:     Services.AlertLevelWarning,
+		Labels:    map[string]string{"host": "web-1"},
+		Enabled:   true,
+	}
+	require.NoError(t, service.AddRule(rule))
+	return rule
+}
+
+func TestAlertFingerprint(t *testing.T) {
+	a := Services.AlertFingerprint("r1", "cpu_usage", map[string]string{"host": "a", "env": "prod"})
+	b := Services.AlertFingerprint("r1", "cpu_usage", map[string]string{"env": "prod", "host": "a"})
+	assert.Equal(t, a, b)
+	assert.Len(t, a, 64)
+	assert.NotEqual(t, a, Services.AlertFingerprint("r1", "cpu_usage", map[string]string{"host": "b", "env": "prod"}))
+	assert.NotEqual(t, a, Services.AlertFingerprint("r2", "cpu_usage", map[string]string{"host": "a", "env": "prod"}))
+}
+
+func TestAlertDeduplication(t *testing.T) {
+	service := Services.NewAlertService(nil, nil)
+	service.SetResolutionPolicy(0, time.Minute, 0)
+	rule := cpuRule(t, service)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, service.CheckAlerts())
+	}
+
+	alerts := service.GetAlerts("", 10)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, 3, alerts[0].Count)
+	assert.Equal(t, "active", alerts[0].Status)
+	assert.Equal(t, Services.AlertFingerprint(rule.ID, rule.Metric, rule.Labels), alerts[0].Fingerprint)
+	assert.False(t, alerts[0].LastSeen.Before(alerts[0].CreatedAt))
+
+	// 没有稳定期时条件恢复立即解决，之后再次触发生成新告警
+	rule.Threshold = 80
+	require.NoError(t, service.CheckAlerts())
+	assert.Len(t, service.GetAlerts("resolved", 10), 1)
+
+	rule.Threshold = 70
+	require.NoError(t, service.CheckAlerts())
+	active := service.GetAlerts("active", 10)
+	require.Len(t, active, 1)
+	assert.Equal(t, 1, active[0].Count)
+}
+
+func TestAlertResolutionWaitsForStability(t *testing.T) {
+	service := Services.NewAlertService(nil, nil)
+	service.SetResolutionPolicy(50*time.Millisecond, time.Minute, 0)
+	rule := cpuRule(t, service)
+
+	require.NoError(t, service.CheckAlerts())
+	rule.Threshold = 80
+	require.NoError(t, service.CheckAlerts())
+
+	alerts := service.GetAlerts("active", 10)
+	require.Len(t, alerts, 1)
+	assert.NotNil(t, alerts[0].ClearedAt)
+
+	time.Sleep(60 * time.Millisecond)
+	require.NoError(t, service.CheckAlerts())
+	assert.Empty(t, service.GetAlerts("active", 10))
+	resolved := service.GetAlerts("resolved", 10)
+	require.Len(t, resolved, 1)
+	assert.NotNil(t, resolved[0].ResolvedAt)
+}
+
+func TestAlertFlapDetectionDelaysResolution(t *testing.T) {
+	service := Services.NewAlertService(nil, nil)
+	service.SetResolutionPolicy(20*time.Millisecond, 150*time.Millisecond, 3)
+	rule := cpuRule(t, service)
+
+	// 触发后反复恢复/触发，切换次数达到阈值
+	require.NoError(t, service.CheckAlerts())
+	for _, threshold := range []float64{80, 70, 80} {
+		rule.Threshold = threshold
+		require.NoError(t, service.CheckAlerts())
+	}
+
+	alerts := service.GetAlerts("", 10)
+	require.Len(t, alerts, 1)
+	assert.True(t, alerts[0].Flapping)
+	assert.Equal(t, 2, alerts[0].Count)
+
+	// 超过普通稳定期但未稳定整个抖动窗口，仍不解决
+	time.Sleep(40 * time.Millisecond)
+	require.NoError(t, service.CheckAlerts())
+	assert.Len(t, service.GetAlerts("active", 10), 1)
+
+	time.Sleep(130 * time.Millisecond)
+	require.NoError(t, service.CheckAlerts())
+	assert.Len(t, service.GetAlerts("resolved", 10), 1)
+}
@@ -0,0 +1,222 @@
+package Monitoring
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Services"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAlertWebhookTestService(t *testing.T, core *Services.MonitoringCore, configure func(config *Config.MonitoringConfig)) *Services.AlertWebhookService {
+	config := &Config.MonitoringConfig{}
+	config.SetDefaults()
+	config.AlertWebhook.Enabled = true
+	config.AlertWebhook.Tokens = "alertmanager:am-secret, zabbix:zbx-secret"
+	if configure != nil {
+		configure(config)
+	}
+	require.NoError(t, config.Validate())
+
+	service := Services.NewAlertWebhookService(config)
+	service.SetMonitoringCore(core)
+	return service
+}
+
+func alertmanagerBody(status string, alerts ...string) []byte {
+	return []byte(`{"version":"4","status":"` + status + `","receiver":"cloud-platform","alerts":[` + strings.Join(alerts, ",") + `]}`)
+}
+
+func diskFullAlert(status string) string {
+	return fmt.Sprintf(`{"status":"%s","labels":{"alertname":"DiskFull","severity":"critical","instance":"db-1"},`+
+		`"annotations":{"summary":"db-1 磁盘使用率 95%%"},"startsAt":"2024-01-01T00:00:00Z","endsAt":"0001-01-01T00:00:00Z",`+
+		`"generatorURL":"http://prometheus/graph"}`, status)
+}
+
+func TestAlertWebhookConfigValidate(t *testing.T) {
+	for _, modify := range []func(*Config.MonitoringConfig){
+		func(c *Config.MonitoringConfig) { c.AlertWebhook.Tokens = "" },
+		func(c *Config.MonitoringConfig) { c.AlertWebhook.Tokens = "alertmanager" },
+		func(c *Config.MonitoringConfig) { c.AlertWebhook.MaxAlerts = 0 },
+		func(c *Config.MonitoringConfig) { c.AlertWebhook.DefaultLevel = "urgent" },
+		func(c *Config.MonitoringConfig) { c.AlertWebhook.Mapping.Name = "" },
+	} {
+		config := &Config.MonitoringConfig{}
+		config.SetDefaults()
+		config.AlertWebhook.Enabled = true
+		config.AlertWebhook.Tokens = "alertmanager:am-secret"
+		modify(config)
+		assert.Error(t, config.Validate())
+	}
+}
+
+func TestAlertmanagerWebhookFeedsAlertWorkflow(t *testing.T) {
+	incidents, core, _ := setupIncidentService(t, time.Hour)
+	channel := newQueuedChannel("webhook")
+	core.Pipeline().AddChannel(channel)
+	service := newAlertWebhookTestService(t, core, nil)
+
+	source, err := service.Authenticate("am-secret")
+	require.NoError(t, err)
+	assert.Equal(t, "alertmanager", source)
+	_, err = service.Authenticate("wrong")
+	assert.ErrorIs(t, err, Services.ErrAlertWebhookUnauthorized)
+
+	firing := diskFullAlert("firing")
+	alerts, err := service.ParseAlertmanager(alertmanagerBody("firing", firing, `{"status":"firing","labels":{"severity":"page"}}`))
+	require.NoError(t, err)
+	require.Len(t, alerts, 2)
+	assert.Equal(t, "DiskFull", alerts[0].Name)
+	assert.Equal(t, Services.AlertLevelCritical, alerts[0].Level)
+	assert.Equal(t, "db-1 磁盘使用率 95%", alerts[0].Message)
+	assert.Equal(t, "http://prometheus/graph", alerts[0].Annotations["generator_url"])
+
+	result, err := service.Receive(source, alerts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Triggered)
+	assert.Equal(t, 1, result.Rejected, "缺少 alertname 的告警被拒绝")
+
+	notification := channel.receive(t)
+	assert.Equal(t, "external_alert", notification.Type)
+	assert.Equal(t, "alertmanager", notification.Source)
+	assert.Equal(t, "critical", notification.Severity)
+
+	active := core.Alerts("active", 0)
+	require.Len(t, active, 1)
+	assert.Equal(t, "alertmanager", active[0].Source)
+	assert.Equal(t, "db-1", active[0].Labels["instance"])
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), active[0].CreatedAt.UTC())
+
+	// Alertmanager 按 repeat_interval 重复推送，只累加次数，不重复通知
+	result, err = service.Receive(source, alerts[:1])
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Repeated)
+	assert.Equal(t, 2, core.Alerts("active", 0)[0].Count)
+	select {
+	case alert := <-channel.alerts:
+		t.Fatalf("重复推送不应发送通知: %+v", alert)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	detail, err := incidents.GetIncident(1)
+	require.NoError(t, err)
+	assert.Equal(t, "external_alertmanager_DiskFull", detail.RuleID)
+	assert.Equal(t, "critical", detail.Severity)
+	_, err = incidents.Acknowledge(1, 1, "处理中")
+	require.NoError(t, err)
+
+	resolved := diskFullAlert("resolved")
+	alerts, err = service.ParseAlertmanager(alertmanagerBody("resolved", resolved))
+	require.NoError(t, err)
+	result, err = service.Receive(source, alerts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Resolved)
+	assert.True(t, channel.receive(t).Resolved)
+	assert.Empty(t, core.Alerts("active", 0))
+
+	result, err = service.Receive(source, alerts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Ignored, "恢复未知告警时忽略")
+
+	detail, err = incidents.GetIncident(1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, detail.ActiveAlerts)
+}
+
+func TestAlertWebhookJSONMapping(t *testing.T) {
+	core := newTestMonitoringCore()
+	service := newAlertWebhookTestService(t, core, func(config *Config.MonitoringConfig) {
+		config.AlertWebhook.Mapping.Alerts = "data.events"
+		config.AlertWebhook.Mapping.Name = "check.name"
+		config.AlertWebhook.Mapping.Status = "state"
+		config.AlertWebhook.Mapping.Severity = "priority"
+		config.AlertWebhook.Mapping.Message = "text"
+		config.AlertWebhook.Mapping.Labels = "tags"
+		config.AlertWebhook.Mapping.StartsAt = "clock"
+	})
+
+	alerts, err := service.ParseJSON([]byte(`{"data":{"events":[
+		{"check":{"name":"PingLoss"},"state":"PROBLEM","priority":"high","text":"丢包率 30%","tags":{"host":"gw-1","port":443},"clock":1700000000},
+		{"check":{"name":"PingLoss"},"state":"OK","tags":{"host":"gw-2"}},
+		{"check":{"name":"Unknown"},"priority":"whatever"}
+	]}}`))
+	require.NoError(t, err)
+	require.Len(t, alerts, 3)
+	assert.Equal(t, Services.ExternalAlert{
+		Name:     "PingLoss",
+		Level:    Services.AlertLevelCritical,
+		Message:  "丢包率 30%",
+		Labels:   map[string]string{"alertname": "PingLoss", "host": "gw-1", "port": "443"},
+		StartsAt: time.Unix(1700000000, 0),
+	}, alerts[0])
+	assert.True(t, alerts[1].Resolved, "状态值不区分大小写")
+	assert.Equal(t, Services.AlertLevelWarning, alerts[2].Level, "无法识别的严重程度使用默认级别")
+	assert.Equal(t, "Unknown", alerts[2].Message)
+
+	result, err := service.Receive("zabbix", alerts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Triggered)
+	assert.Equal(t, 1, result.Ignored)
+
+	_, err = service.ParseJSON([]byte(`{"data":{}}`))
+	assert.Error(t, err)
+	_, err = service.ParseJSON([]byte(`"text"`))
+	assert.Error(t, err)
+}
+
+func TestAlertWebhookEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	core := newTestMonitoringCore()
+	service := newAlertWebhookTestService(t, core, func(config *Config.MonitoringConfig) {
+		config.AlertWebhook.MaxBodySize = 2048
+		config.AlertWebhook.MaxAlerts = 2
+	})
+	controller := Controllers.NewMonitoringController()
+	router := gin.New()
+	router.POST("/alertmanager", controller.ReceiveAlertmanagerWebhook)
+	router.POST("/json", controller.ReceiveJSONAlertWebhook)
+	post := func(path, token string, body []byte) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	firing := diskFullAlert("firing")
+	assert.Equal(t, http.StatusServiceUnavailable, post("/alertmanager", "am-secret", alertmanagerBody("firing", firing)).Code)
+	controller.SetAlertWebhookService(service)
+
+	assert.Equal(t, http.StatusUnauthorized, post("/alertmanager", "", alertmanagerBody("firing", firing)).Code)
+	assert.Equal(t, http.StatusBadRequest, post("/alertmanager", "am-secret", []byte("{")).Code)
+	assert.Equal(t, http.StatusBadRequest, post("/alertmanager", "am-secret", []byte(`{"version":"3","alerts":[]}`)).Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post("/alertmanager", "am-secret", bytes.Repeat([]byte(" "), 3000)).Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post("/json", "zbx-secret", []byte(`[{"name":"a"},{"name":"b"},{"name":"c"}]`)).Code)
+
+	w := post("/alertmanager", "am-secret", alertmanagerBody("firing", firing))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Data Services.AlertWebhookResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, Services.AlertWebhookResult{Source: "alertmanager", Triggered: 1}, resp.Data)
+
+	w = post("/json", "zbx-secret", []byte(`{"name":"DiskFull","severity":"error","labels":{"instance":"db-1"}}`))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 1, resp.Data.Triggered, "不同来源的同名告警分别去重")
+	assert.Len(t, core.Alerts("active", 0), 2)
+}
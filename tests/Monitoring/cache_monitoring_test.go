@@ -0,0 +1,175 @@
+package Monitoring
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Services"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const redisInfo = `# Server
+redis_version:7.2.4
+tcp_port:6379
+
+# Clients
+connected_clients:12
+
+# Memory
+used_memory:900
+maxmemory:1000
+total_system_memory:8000
+
+# Stats
+keyspace_hits:80
+keyspace_misses:20
+evicted_keys:5
+expired_keys:7
+
+# Replication
+role:master
+
+# Keyspace
+db0:keys=10,expires=4,avg_ttl=1000
+db2:keys=5,expires=1,avg_ttl=0
+`
+
+func cacheMonitoringService(t *testing.T) *Services.CacheMonitoringService {
+	config := &Config.MonitoringConfig{}
+	config.SetDefaults()
+	config.CacheMonitoring.HitRateThreshold = 90
+	config.CacheMonitoring.MemoryUsageThreshold = 85
+	config.CacheMonitoring.ConnectionThreshold = 10
+	config.CacheMonitoring.EvictionThreshold = 100
+	config.CacheMonitoring.ExpiredKeysThreshold = 100
+	config.CacheMonitoring.AlertCooldown = time.Hour
+
+	service := Services.NewCacheMonitoringService(config, &Config.RedisConfig{Host: "127.0.0.1", Port: 6379})
+	t.Cleanup(func() { service.Close() })
+	return service
+}
+
+func TestParseCacheNodeMetrics(t *testing.T) {
+	node := Services.ParseCacheNodeMetrics("10.0.0.1:6379", strings.ReplaceAll(redisInfo, "\n", "\r\n"))
+
+	assert.Equal(t, "10.0.0.1:6379", node.Addr)
+	assert.Equal(t, "master", node.Role)
+	assert.Equal(t, int64(12), node.ConnectedClients)
+	assert.Equal(t, int64(1000), node.MaxMemory)
+	assert.InDelta(t, 90.0, node.MemoryUsage, 0.001)
+	assert.Equal(t, int64(80), node.KeyspaceHits)
+	assert.Equal(t, int64(20), node.KeyspaceMisses)
+	assert.Equal(t, int64(5), node.EvictedKeys)
+	assert.Equal(t, int64(7), node.ExpiredKeys)
+	assert.Equal(t, int64(15), node.Keys)
+	assert.Equal(t, int64(5), node.Expires)
+
+	// 未设置 maxmemory 时按系统内存计算
+	node = Services.ParseCacheNodeMetrics("", strings.Replace(redisInfo, "maxmemory:1000", "maxmemory:0", 1))
+	assert.Equal(t, int64(8000), node.MaxMemory)
+	assert.InDelta(t, 11.25, node.MemoryUsage, 0.001)
+}
+
+func TestCacheMetricsRecordDeltas(t *testing.T) {
+	service := cacheMonitoringService(t)
+
+	node := Services.ParseCacheNodeMetrics("a:6379", redisInfo)
+	other := node
+	other.Addr = "b:6379"
+	first := service.Record([]Services.CacheNodeMetrics{other, node}, map[string]int64{"persistent": 3, "lt_1m": 1})
+
+	// 首次采集：命中率按累计值，驱逐和过期增量为0
+	assert.Equal(t, Config.RedisModeStandalone, first.Mode)
+	assert.Equal(t, "a:6379", first.Nodes[0].Addr)
+	assert.InDelta(t, 80.0, first.HitRate, 0.001)
+	assert.Zero(t, first.EvictedKeys)
+	assert.Equal(t, int64(24), first.ConnectedClients)
+	assert.Equal(t, int64(4), first.SampledKeys)
+	assert.Equal(t, int64(0), first.TTLDistribution["gt_1d"])
+
+	// 第二次采集按增量计算；b 节点计数归零视为重启
+	node.KeyspaceHits, node.KeyspaceMisses = 90, 60
+	node.EvictedKeys, node.ExpiredKeys = 205, 8
+	other.KeyspaceHits, other.KeyspaceMisses, other.EvictedKeys, other.ExpiredKeys = 0, 0, 1, 0
+	second := service.Record([]Services.CacheNodeMetrics{node, other}, nil)
+	assert.Equal(t, int64(10), second.KeyspaceHits)
+	assert.Equal(t, int64(40), second.KeyspaceMisses)
+	assert.InDelta(t, 20.0, second.HitRate, 0.001)
+	assert.Equal(t, int64(201), second.EvictedKeys)
+	assert.Equal(t, int64(1), second.ExpiredKeys)
+	assert.Same(t, second, service.GetMetrics())
+}
+
+func TestCacheMetricsThresholdAlerts(t *testing.T) {
+	service := cacheMonitoringService(t)
+
+	node := Services.ParseCacheNodeMetrics("a:6379", redisInfo)
+	service.Record([]Services.CacheNodeMetrics{node}, nil)
+	node.EvictedKeys = 500
+	metrics := service.Record([]Services.CacheNodeMetrics{node}, nil)
+	metrics.KeyspaceHits, metrics.KeyspaceMisses, metrics.HitRate = 50, 50, 50
+
+	alerts := service.Evaluate(metrics)
+	var types []string
+	for _, alert := range alerts {
+		types = append(types, alert.Type)
+	}
+	assert.ElementsMatch(t, []string{
+		Services.CacheAlertHitRateLow,
+		Services.CacheAlertMemoryHigh,
+		Services.CacheAlertConnectionsHigh,
+		Services.CacheAlertEvictionsHigh,
+	}, types)
+
+	// 冷却时间内不重复告警，但当前告警列表仍然可查
+	assert.Empty(t, service.Evaluate(metrics))
+	assert.Len(t, service.GetAlerts(), 4)
+
+	// 检查间隔内没有访问时不检查命中率
+	metrics.KeyspaceHits, metrics.KeyspaceMisses, metrics.HitRate = 0, 0, 0
+	service.Evaluate(metrics)
+	for _, alert := range service.GetAlerts() {
+		assert.NotEqual(t, Services.CacheAlertHitRateLow, alert.Type)
+	}
+}
+
+func TestTTLBucket(t *testing.T) {
+	cases := map[time.Duration]string{
+		-1:                   "persistent",
+		30 * time.Second:     "lt_1m",
+		10 * time.Minute:     "1m_1h",
+		3 * time.Hour:        "1h_1d",
+		72 * time.Hour:       "gt_1d",
+		24*time.Hour - 1:     "1h_1d",
+		time.Minute:          "1m_1h",
+		0 * time.Second:      "lt_1m",
+		time.Duration(-2):    "persistent",
+		365 * 24 * time.Hour: "gt_1d",
+	}
+	for ttl, expected := range cases {
+		assert.Equal(t, expected, Services.TTLBucket(ttl), ttl.String())
+	}
+}
+
+func TestRedisTopologyConfig(t *testing.T) {
+	cluster := &Config.RedisConfig{Mode: "Cluster", Addrs: "10.0.0.1:7000, 10.0.0.2:7000,"}
+	require.NoError(t, cluster.Validate())
+	assert.Equal(t, []string{"10.0.0.1:7000", "10.0.0.2:7000"}, cluster.GetAddrs())
+	assert.True(t, cluster.IsConfigured())
+
+	sentinel := &Config.RedisConfig{Mode: Config.RedisModeSentinel, Addrs: "10.0.0.1:26379"}
+	assert.Error(t, sentinel.Validate())
+	sentinel.MasterName = "mymaster"
+	assert.NoError(t, sentinel.Validate())
+
+	standalone := &Config.RedisConfig{Host: "localhost", Port: 6379, Database: 3}
+	require.NoError(t, standalone.Validate())
+	assert.Equal(t, []string{"localhost:6379"}, standalone.GetAddrs())
+	assert.Equal(t, 3, standalone.GetDB())
+
+	assert.Error(t, (&Config.RedisConfig{Mode: "replica", Host: "localhost", Port: 6379}).Validate())
+	assert.False(t, (&Config.RedisConfig{}).IsConfigured())
+}
@@ -0,0 +1,229 @@
+package Monitoring
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Services"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cardinalityConfig(configure func(config *Config.MonitoringConfig)) *Config.MonitoringConfig {
+	config := &Config.MonitoringConfig{}
+	config.SetDefaults()
+	config.Cardinality.MaxSeries = 4
+	config.Cardinality.Limits = "api_latency:2"
+	config.Cardinality.MaxLabels = 3
+	config.Cardinality.MaxLabelValueLength = 10
+	config.Cardinality.WarnRatio = 0.75
+	if configure != nil {
+		configure(config)
+	}
+	return config
+}
+
+func TestCardinalityConfigValidate(t *testing.T) {
+	assert.NoError(t, cardinalityConfig(nil).Validate())
+
+	for _, modify := range []func(*Config.MonitoringConfig){
+		func(c *Config.MonitoringConfig) { c.Cardinality.MaxSeries = 0 },
+		func(c *Config.MonitoringConfig) { c.Cardinality.Action = "drop" },
+		func(c *Config.MonitoringConfig) { c.Cardinality.Limits = "api_latency" },
+		func(c *Config.MonitoringConfig) { c.Cardinality.Limits = "api_latency:0" },
+		func(c *Config.MonitoringConfig) { c.Cardinality.WarnRatio = 1.5 },
+		func(c *Config.MonitoringConfig) { c.Cardinality.SeriesTTL = 0 },
+	} {
+		config := cardinalityConfig(modify)
+		assert.Error(t, config.Validate())
+	}
+
+	config := cardinalityConfig(func(c *Config.MonitoringConfig) {
+		c.Cardinality.Enabled = false
+		c.Cardinality.Action = "drop"
+	})
+	assert.NoError(t, config.Validate(), "未启用时不检查")
+}
+
+func TestMetricCardinalityGuardLabelValidation(t *testing.T) {
+	guard := Services.NewMetricCardinalityGuard(cardinalityConfig(nil))
+
+	for _, labels := range []map[string]string{
+		{"a": "1", "b": "2", "c": "3", "d": "4"},
+		{"bad-name": "1"},
+		{"__name__": "x"},
+		{"path": "/api/v1/users/12345"},
+	} {
+		_, err := guard.Check("requests", labels)
+		assert.ErrorIs(t, err, Services.ErrInvalidMetricLabels, fmt.Sprint(labels))
+	}
+
+	labels, err := guard.Check("requests", map[string]string{"method": "GET"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"method": "GET"}, labels)
+
+	stats := guard.Top(0)
+	require.Len(t, stats, 1)
+	assert.Equal(t, int64(4), stats[0].InvalidLabels)
+	assert.Equal(t, 1, stats[0].Series)
+
+	var disabled *Services.MetricCardinalityGuard
+	labels, err = disabled.Check("requests", map[string]string{"bad-name": "1"})
+	assert.NoError(t, err, "未设置基数保护时标签原样通过")
+	assert.Equal(t, map[string]string{"bad-name": "1"}, labels)
+}
+
+func TestMetricCardinalityGuardAggregateAndAlerts(t *testing.T) {
+	guard := Services.NewMetricCardinalityGuard(cardinalityConfig(nil))
+	now := time.Now()
+	guard.SetClock(func() time.Time { return now })
+	var alerts []Services.MonitoringAlert
+	guard.OnAlert(func(alert Services.MonitoringAlert) { alerts = append(alerts, alert) })
+
+	for i := 0; i < 3; i++ {
+		labels, err := guard.Check("requests", map[string]string{"user": fmt.Sprint(i)})
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprint(i), labels["user"])
+	}
+	require.Len(t, alerts, 1, "达到上限的75%时告警")
+	assert.Equal(t, Services.MetricCardinalityAlertWarning, alerts[0].Type)
+	assert.Equal(t, "medium", alerts[0].Severity)
+
+	_, err := guard.Check("requests", map[string]string{"user": "3"})
+	require.NoError(t, err)
+	assert.Len(t, alerts, 1, "冷却时间内不重复告警")
+
+	labels, err := guard.Check("requests", map[string]string{"user": "4"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{Services.MetricCardinalityOverflowLabel: "true"}, labels, "超过上限的新标签组合合并为 overflow 序列")
+	labels, err = guard.Check("requests", map[string]string{"user": "0"})
+	require.NoError(t, err)
+	assert.Equal(t, "0", labels["user"], "已跟踪的标签组合不受影响")
+	require.Len(t, alerts, 2)
+	assert.Equal(t, Services.MetricCardinalityAlertExceeded, alerts[1].Type)
+	assert.Equal(t, "high", alerts[1].Severity)
+	assert.Equal(t, "requests", alerts[1].Metadata["metric"])
+
+	// 超过 SeriesTTL 未出现的标签组合不再计数
+	now = now.Add(2 * time.Hour)
+	labels, err = guard.Check("requests", map[string]string{"user": "5"})
+	require.NoError(t, err)
+	assert.Equal(t, "5", labels["user"])
+
+	stats := guard.Top(0)
+	require.Len(t, stats, 1)
+	assert.Equal(t, 1, stats[0].Series)
+	assert.Equal(t, int64(1), stats[0].Aggregated)
+	require.NotNil(t, stats[0].LastOverflowAt)
+}
+
+func TestMetricCardinalityGuardRejectAndTop(t *testing.T) {
+	guard := Services.NewMetricCardinalityGuard(cardinalityConfig(func(config *Config.MonitoringConfig) {
+		config.Cardinality.Action = Config.MetricCardinalityReject
+	}))
+	assert.Equal(t, 2, guard.Limit("api_latency"))
+	assert.Equal(t, 4, guard.Limit("requests"))
+
+	for i := 0; i < 3; i++ {
+		_, err := guard.Check("api_latency", map[string]string{"route": fmt.Sprint(i)})
+		if i < 2 {
+			require.NoError(t, err)
+		} else {
+			assert.ErrorIs(t, err, Services.ErrMetricCardinalityExceeded)
+		}
+	}
+	for _, method := range []string{"GET", "POST"} {
+		_, err := guard.Check("requests", map[string]string{"method": method})
+		require.NoError(t, err)
+	}
+	_, err := guard.Check("queue_depth", nil)
+	require.NoError(t, err)
+
+	stats := guard.Top(2)
+	require.Len(t, stats, 2)
+	assert.Equal(t, "api_latency", stats[0].Metric)
+	assert.Equal(t, 1.0, stats[0].Usage)
+	assert.Equal(t, int64(1), stats[0].Rejected)
+	assert.Equal(t, "requests", stats[1].Metric)
+}
+
+func TestMetricIngestCardinalityGuard(t *testing.T) {
+	service, core := newIngestTestService(t, nil)
+	service.SetCardinalityGuard(Services.NewMetricCardinalityGuard(cardinalityConfig(nil)))
+
+	result, err := service.Ingest("billing", []Services.IngestSample{
+		{Name: "api_latency", Value: 1, Labels: map[string]string{"route": "a"}},
+		{Name: "api_latency", Value: 2, Labels: map[string]string{"route": "b"}},
+		{Name: "api_latency", Value: 3, Labels: map[string]string{"route": "c"}},
+		{Name: "api_latency", Value: 4, Labels: map[string]string{"route": strings.Repeat("x", 20)}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Accepted)
+	assert.Equal(t, 1, result.Rejected)
+
+	values := core.Values()
+	assert.Equal(t, 2.0, values[`api_latency{route="b"}`])
+	assert.Equal(t, 3.0, values[`api_latency{overflow="true"}`])
+	assert.NotContains(t, values, `api_latency{route="c"}`)
+}
+
+func TestCustomMetricCardinalityEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	monitoringService := Services.NewOptimizedMonitoringService()
+	monitoringService.SetCardinalityGuard(Services.NewMetricCardinalityGuard(cardinalityConfig(func(config *Config.MonitoringConfig) {
+		config.Cardinality.Action = Config.MetricCardinalityReject
+	})))
+
+	performanceController := Controllers.NewPerformanceMonitoringController()
+	performanceController.SetPerformanceMonitoringService(monitoringService)
+	monitoringController := Controllers.NewMonitoringController()
+	router := gin.New()
+	router.POST("/custom-metrics", performanceController.RecordCustomMetric)
+	router.GET("/cardinality", monitoringController.GetCardinality)
+
+	record := func(labels map[string]string) int {
+		body, _ := json.Marshal(map[string]interface{}{
+			"metric_type": "business", "metric_name": "api_latency", "value": 1, "labels": labels,
+		})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/custom-metrics", bytes.NewReader(body)))
+		return w.Code
+	}
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/cardinality"+query, nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusInternalServerError, get("").Code)
+	monitoringController.SetMonitoringService(monitoringService)
+
+	assert.Equal(t, http.StatusOK, record(map[string]string{"route": "a"}))
+	assert.Equal(t, http.StatusOK, record(map[string]string{"route": "b"}))
+	assert.Equal(t, http.StatusUnprocessableEntity, record(map[string]string{"route": "c"}))
+	assert.Equal(t, http.StatusBadRequest, record(map[string]string{"bad-name": "a"}))
+
+	assert.Equal(t, http.StatusBadRequest, get("?limit=0").Code)
+	w := get("?limit=5")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Data struct {
+			Metrics []Services.MetricCardinalityStat `json:"metrics"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data.Metrics, 1)
+	assert.Equal(t, "api_latency", resp.Data.Metrics[0].Metric)
+	assert.Equal(t, 2, resp.Data.Metrics[0].Series)
+	assert.Equal(t, int64(1), resp.Data.Metrics[0].Rejected)
+	assert.Equal(t, int64(1), resp.Data.Metrics[0].InvalidLabels)
+}
@@ -0,0 +1,254 @@
+package Monitoring
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Services"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startTLSServer 使用测试CA签发的证书启动TLS服务，证书在 validFor 后过期，返回监听地址和CA证书池
+func startTLSServer(t *testing.T, validFor time.Duration) (string, *x509.CertPool) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root CA", Organization: []string{"Cloud Platform"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(0x2a),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost", "api.example.com"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(validFor),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, caCert, &leafKey.PublicKey, caKey)
+	require.NoError(t, err)
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{leafDER, caDER}, PrivateKey: leafKey}},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = conn.(*tls.Conn).Handshake()
+			}()
+		}
+	}()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	return listener.Addr().String(), roots
+}
+
+// startRDAPServer 模拟RDAP服务，example.com 在 expiresIn 后到期，其他域名返回404
+func startRDAPServer(t *testing.T, expiresIn time.Duration) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/domain/example.com" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rdap+json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"ldhName": "EXAMPLE.COM",
+			"events": []map[string]string{
+				{"eventAction": "registration", "eventDate": "1995-08-14T04:00:00Z"},
+				{"eventAction": "expiration", "eventDate": time.Now().Add(expiresIn).UTC().Format(time.RFC3339)},
+			},
+			"entities": []map[string]interface{}{{
+				"roles":      []string{"registrar"},
+				"vcardArray": []interface{}{"vcard", [][]interface{}{{"version", map[string]string{}, "text", "4.0"}, {"fn", map[string]string{}, "text", "Example Registrar, Inc."}}},
+			}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestCertificateCollector(t *testing.T, targets, domains []string, rdapURL string) *Services.CertificateExpiryCollector {
+	config := &Config.MonitoringConfig{}
+	config.SetDefaults()
+	config.Collectors.Certificates.Enabled = true
+	config.Collectors.Certificates.Targets = strings.Join(targets, ",")
+	config.Collectors.Certificates.Domains = strings.Join(domains, ",")
+	config.Collectors.Certificates.RDAPURL = rdapURL
+	require.NoError(t, config.Validate())
+
+	collector, err := Services.NewCertificateExpiryCollectorFromConfig(config)
+	require.NoError(t, err)
+	collector.SetHTTPClient(Services.NewOutboundHTTPClient(config))
+	return collector
+}
+
+func TestCertificateExpiryCollector(t *testing.T) {
+	target, roots := startTLSServer(t, 10*24*time.Hour)
+	rdap := startRDAPServer(t, 20*24*time.Hour)
+
+	collector := newTestCertificateCollector(t, []string{target}, []string{"example.com", "unknown.test"}, rdap.URL+"/domain/")
+	collector.SetRootCAs(roots)
+	metrics, err := collector.Collect(context.Background())
+	require.NoError(t, err)
+
+	days := metrics[`tls_certificate_expiry_days{target="`+target+`"}`]
+	assert.InDelta(t, 10, days, 0.05)
+	assert.Equal(t, 1.0, metrics[`tls_certificate_chain_valid{target="`+target+`"}`])
+	assert.InDelta(t, 20, metrics[`domain_expiry_days{domain="example.com"}`], 0.05)
+	assert.Equal(t, 1.0, metrics["certificate_check_failures"])
+
+	certificates := collector.Certificates()
+	require.Len(t, certificates, 1)
+	info := certificates[0]
+	assert.Equal(t, target, info.Target)
+	assert.Equal(t, "CN=localhost", info.Subject)
+	assert.Contains(t, info.Issuer, "CN=Test Root CA")
+	assert.Equal(t, []string{"localhost", "api.example.com", "127.0.0.1"}, info.SANs)
+	assert.Equal(t, "2a", info.SerialNumber)
+	assert.True(t, info.ChainValid)
+	assert.Empty(t, info.ChainError)
+
+	domains := collector.Domains()
+	require.Len(t, domains, 2)
+	assert.Equal(t, "unknown.test", domains[0].Domain)
+	assert.Contains(t, domains[0].Error, "404")
+	assert.Equal(t, "example.com", domains[1].Domain)
+	assert.Equal(t, "Example Registrar, Inc.", domains[1].Registrar)
+
+	// 不信任测试CA时证书链校验失败，但仍然读取到期时间
+	untrusted := newTestCertificateCollector(t, []string{target}, nil, "")
+	metrics, err = untrusted.Collect(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, metrics[`tls_certificate_chain_valid{target="`+target+`"}`])
+	assert.InDelta(t, 10, metrics[`tls_certificate_expiry_days{target="`+target+`"}`], 0.05)
+	assert.NotEmpty(t, untrusted.Certificates()[0].ChainError)
+
+	// 全部检查失败时返回错误
+	unreachable := newTestCertificateCollector(t, []string{"127.0.0.1:1"}, nil, "")
+	_, err = unreachable.Collect(context.Background())
+	assert.Error(t, err)
+	assert.NotEmpty(t, unreachable.Certificates()[0].Error)
+}
+
+func TestCertificateExpiryAlertThresholds(t *testing.T) {
+	target, roots := startTLSServer(t, 10*24*time.Hour)
+	rdap := startRDAPServer(t, 20*24*time.Hour)
+
+	collector := newTestCertificateCollector(t, []string{target}, []string{"example.com"}, rdap.URL+"/domain/")
+	collector.SetRootCAs(roots)
+	assert.Equal(t, []int{30, 14, 7}, collector.Thresholds())
+
+	core := newTestMonitoringCore()
+	require.NoError(t, core.RegisterCollector(collector))
+	for _, rule := range collector.AlertRules() {
+		require.NoError(t, core.AddRule(rule))
+	}
+	core.Collect()
+	require.NoError(t, core.CheckAlerts())
+
+	levels := map[string]Services.AlertLevel{}
+	for _, alert := range core.Alerts("active", 0) {
+		levels[alert.RuleID] = alert.Level
+	}
+	// 证书10天后过期触发30天和14天两档，域名20天后到期只触发30天一档，证书链有效不告警
+	assert.Equal(t, map[string]Services.AlertLevel{
+		"tls_certificate_expiry_30d_" + target: Services.AlertLevelWarning,
+		"tls_certificate_expiry_14d_" + target: Services.AlertLevelError,
+		"domain_expiry_30d_example.com":        Services.AlertLevelWarning,
+	}, levels)
+
+	var critical *Services.AlertRule
+	for _, rule := range collector.AlertRules() {
+		if rule.ID == "tls_certificate_expiry_7d_"+target {
+			critical = rule
+		}
+	}
+	require.NotNil(t, critical)
+	assert.Equal(t, Services.AlertLevelCritical, critical.Level)
+}
+
+func TestCertificateConfigValidation(t *testing.T) {
+	config := &Config.MonitoringConfig{}
+	config.SetDefaults()
+	config.Collectors.Certificates.Enabled = true
+	assert.Error(t, config.Validate())
+
+	config.Collectors.Certificates.Targets = "example.com"
+	require.NoError(t, config.Validate())
+	config.Collectors.Certificates.Thresholds = "30,abc"
+	assert.Error(t, config.Validate())
+	config.Collectors.Certificates.Thresholds = "30,0"
+	assert.Error(t, config.Validate())
+}
+
+func TestCertificatesEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	target, roots := startTLSServer(t, 45*24*time.Hour)
+
+	controller := Controllers.NewMonitoringController()
+	router := gin.New()
+	router.GET("/certificates", controller.GetCertificates)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/certificates", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	collector := newTestCertificateCollector(t, []string{target}, nil, "")
+	collector.SetRootCAs(roots)
+	_, err := collector.Collect(context.Background())
+	require.NoError(t, err)
+	controller.SetCertificateCollector(collector)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/certificates", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data struct {
+			Certificates []Services.CertificateInfo `json:"certificates"`
+			Thresholds   []int                      `json:"thresholds"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Data.Certificates, 1)
+	assert.True(t, response.Data.Certificates[0].ChainValid)
+	assert.Contains(t, response.Data.Certificates[0].SANs, "api.example.com")
+	assert.Equal(t, []int{30, 14, 7}, response.Data.Thresholds)
+}
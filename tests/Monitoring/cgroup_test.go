@@ -0,0 +1,135 @@
+package Monitoring
+
+import (
+	"cloud-platform-api/app/Services"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCgroupFiles 在 root 下写入模拟的cgroup文件
+func writeCgroupFiles(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func TestCgroupV2Metrics(t *testing.T) {
+	root := t.TempDir()
+	writeCgroupFiles(t, root, map[string]string{
+		"cgroup.controllers": "cpuset cpu io memory pids\n",
+		"cpu.max":            "50000 100000\n",
+		"cpu.stat":           "usage_usec 10000000\nuser_usec 8000000\nsystem_usec 2000000\nnr_periods 100\nnr_throttled 10\nthrottled_usec 500000\n",
+		"memory.current":     "314572800\n",
+		"memory.max":         "536870912\n",
+		"memory.stat":        "anon 209715200\nfile 104857600\ninactive_file 52428800\n",
+		"memory.events":      "low 0\nhigh 0\nmax 3\noom 1\noom_kill 1\n",
+	})
+
+	collector, err := Services.NewCgroupMetricsCollector(root)
+	require.NoError(t, err)
+	assert.Equal(t, "v2", collector.Version())
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	collector.SetClock(func() time.Time { return now })
+
+	metrics, err := collector.Collect(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, metrics["container_cpu_limit_cores"])
+	assert.Equal(t, 10.0, metrics["container_cpu_usage_seconds_total"])
+	assert.Equal(t, 0.5, metrics["container_cpu_throttled_seconds_total"])
+	assert.Equal(t, 536870912.0, metrics["container_memory_limit_bytes"])
+	assert.Equal(t, 262144000.0, metrics["container_memory_working_set_bytes"])
+	assert.Equal(t, 1.0, metrics["container_memory_oom_kills_total"])
+	assert.InDelta(t, 48.83, metrics["memory_usage"], 0.01, "按工作集占内存限额计算")
+	_, hasCPU := metrics["cpu_usage"]
+	assert.False(t, hasCPU, "首次采集没有差值，不输出CPU使用率")
+
+	// 10秒内使用了4秒CPU、配额0.5核：使用率80%；200个周期中有50个被限流：25%
+	now = now.Add(10 * time.Second)
+	writeCgroupFiles(t, root, map[string]string{
+		"cpu.stat": "usage_usec 14000000\nnr_periods 300\nnr_throttled 60\nthrottled_usec 900000\n",
+	})
+	metrics, err = collector.Collect(context.Background())
+	require.NoError(t, err)
+	assert.InDelta(t, 80, metrics["cpu_usage"], 0.001)
+	assert.InDelta(t, 25, metrics["container_cpu_throttled_ratio"], 0.001)
+	assert.Equal(t, 60.0, metrics["container_cpu_throttled_periods_total"])
+}
+
+func TestCgroupV1Metrics(t *testing.T) {
+	root := t.TempDir()
+	writeCgroupFiles(t, root, map[string]string{
+		"cpu,cpuacct/cpu.cfs_quota_us":  "200000\n",
+		"cpu,cpuacct/cpu.cfs_period_us": "100000\n",
+		"cpu,cpuacct/cpu.stat":          "nr_periods 40\nnr_throttled 4\nthrottled_time 2000000000\n",
+		"cpu,cpuacct/cpuacct.usage":     "30000000000\n",
+		"memory/memory.usage_in_bytes":  "104857600\n",
+		"memory/memory.limit_in_bytes":  "9223372036854771712\n",
+		"memory/memory.stat":            "cache 20971520\ntotal_inactive_file 10485760\n",
+		"memory/memory.oom_control":     "oom_kill_disable 0\nunder_oom 0\noom_kill 2\n",
+	})
+
+	collector, err := Services.NewCgroupMetricsCollector(root)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", collector.Version())
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	collector.SetClock(func() time.Time { return now })
+
+	metrics, err := collector.Collect(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, metrics["container_cpu_limit_cores"])
+	assert.Equal(t, 30.0, metrics["container_cpu_usage_seconds_total"])
+	assert.Equal(t, 2.0, metrics["container_cpu_throttled_seconds_total"])
+	assert.Equal(t, 94371840.0, metrics["container_memory_working_set_bytes"])
+	assert.Equal(t, 2.0, metrics["container_memory_oom_kills_total"])
+	_, hasLimit := metrics["container_memory_limit_bytes"]
+	assert.False(t, hasLimit, "未设置内存限额")
+	_, hasMemory := metrics["memory_usage"]
+	assert.False(t, hasMemory, "未设置内存限额时保留运行时采集器的值")
+
+	now = now.Add(5 * time.Second)
+	writeCgroupFiles(t, root, map[string]string{"cpu,cpuacct/cpuacct.usage": "35000000000\n"})
+	metrics, err = collector.Collect(context.Background())
+	require.NoError(t, err)
+	assert.InDelta(t, 50, metrics["cpu_usage"], 0.001, "5秒内使用5秒CPU、配额2核")
+	assert.Equal(t, 0.0, metrics["container_cpu_throttled_ratio"])
+}
+
+func TestCgroupCollectorOverridesRuntimeUsage(t *testing.T) {
+	_, err := Services.NewCgroupMetricsCollector(t.TempDir())
+	assert.True(t, errors.Is(err, Services.ErrCgroupNotDetected))
+
+	root := t.TempDir()
+	writeCgroupFiles(t, root, map[string]string{
+		"cgroup.controllers": "cpu memory\n",
+		"cpu.max":            "max 100000\n",
+		"cpu.stat":           "usage_usec 0\nnr_periods 0\nnr_throttled 0\nthrottled_usec 0\n",
+		"memory.current":     "943718400\n",
+		"memory.max":         "1073741824\n",
+	})
+	collector, err := Services.NewCgroupMetricsCollector(root)
+	require.NoError(t, err)
+
+	core := newTestMonitoringCore()
+	require.NoError(t, core.RegisterCollector(collector))
+	require.NoError(t, core.AddRule(&Services.AlertRule{
+		ID: "container_memory", Name: "容器内存", Metric: "memory_usage", Condition: ">", Threshold: 80,
+		Level: Services.AlertLevelWarning, Enabled: true,
+	}))
+	values := core.Collect()
+	assert.InDelta(t, 87.89, values["memory_usage"], 0.01)
+	_, hasLimit := values["container_cpu_limit_cores"]
+	assert.False(t, hasLimit, "未设置CPU配额")
+
+	require.NoError(t, core.CheckAlerts())
+	require.Len(t, core.Alerts("active", 0), 1)
+	assert.Equal(t, "container_memory", core.Alerts("active", 0)[0].RuleID)
+}
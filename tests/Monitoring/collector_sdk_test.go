@@ -0,0 +1,191 @@
+package Monitoring
+
+import (
+	"cloud-platform-api/app/Services"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lifecycleCollector 记录初始化、采集和关闭次数的采集器
+type lifecycleCollector struct {
+	name     string
+	initErr  error
+	inits    int32
+	collects int32
+	closes   int32
+}
+
+func (c *lifecycleCollector) Name() string { return c.name }
+
+func (c *lifecycleCollector) Init(ctx context.Context) error {
+	atomic.AddInt32(&c.inits, 1)
+	return c.initErr
+}
+
+func (c *lifecycleCollector) Collect(ctx context.Context) (map[string]float64, error) {
+	n := atomic.AddInt32(&c.collects, 1)
+	return map[string]float64{c.name + "_collects": float64(n)}, nil
+}
+
+func (c *lifecycleCollector) Close() error {
+	atomic.AddInt32(&c.closes, 1)
+	return nil
+}
+
+func findCollectorStatus(t *testing.T, core *Services.MonitoringCore, name string) Services.CollectorStatus {
+	for _, status := range core.CollectorStatuses() {
+		if status.Name == name {
+			return status
+		}
+	}
+	t.Fatalf("采集器 %s 未注册", name)
+	return Services.CollectorStatus{}
+}
+
+func TestCollectorLifecycleHooks(t *testing.T) {
+	core := newTestMonitoringCore()
+
+	failing := &lifecycleCollector{name: "failing", initErr: errors.New("missing credentials")}
+	assert.Error(t, core.RegisterCollector(failing))
+	assert.NotContains(t, core.Collectors(), "failing")
+	assert.Error(t, core.RegisterCollector(Services.NewMetricCollector("", nil)))
+	assert.Error(t, core.RegisterCollectorWithOptions(&lifecycleCollector{name: "negative"}, Services.CollectorOptions{Interval: -time.Second}))
+
+	first := &lifecycleCollector{name: "orders"}
+	require.NoError(t, core.RegisterCollector(first))
+	assert.Equal(t, int32(1), first.inits)
+	assert.Equal(t, 1.0, core.Collect()["orders_collects"])
+
+	// 同名替换时关闭旧采集器
+	second := &lifecycleCollector{name: "orders"}
+	require.NoError(t, core.RegisterCollector(second))
+	assert.Equal(t, int32(1), first.closes)
+	assert.Equal(t, []string{"runtime", "orders"}, core.Collectors())
+
+	core.UnregisterCollector("orders")
+	assert.Equal(t, int32(1), second.closes)
+
+	third := &lifecycleCollector{name: "payments"}
+	require.NoError(t, core.RegisterCollector(third))
+	core.Close()
+	assert.Equal(t, int32(1), third.closes)
+	assert.Empty(t, core.Collectors())
+}
+
+func TestCollectorTimeoutAndPanicIsolation(t *testing.T) {
+	core := newTestMonitoringCore()
+	release := make(chan struct{})
+	defer close(release)
+
+	require.NoError(t, core.RegisterCollectorWithOptions(Services.NewMetricCollector("slow", func(ctx context.Context) (map[string]float64, error) {
+		<-release
+		return map[string]float64{"slow_value": 1}, nil
+	}), Services.CollectorOptions{Timeout: 50 * time.Millisecond}))
+	require.NoError(t, core.RegisterCollector(Services.NewMetricCollector("panicking", func(ctx context.Context) (map[string]float64, error) {
+		panic("nil map")
+	})))
+	require.NoError(t, core.RegisterCollector(Services.NewMetricCollector("healthy", func(ctx context.Context) (map[string]float64, error) {
+		return map[string]float64{"healthy_value": 2}, nil
+	})))
+
+	started := time.Now()
+	values := core.Collect()
+	assert.Less(t, time.Since(started), time.Second)
+	assert.Equal(t, 2.0, values["healthy_value"])
+	assert.NotContains(t, values, "slow_value")
+
+	slow := findCollectorStatus(t, core, "slow")
+	assert.Contains(t, slow.LastError, "超时")
+	assert.Equal(t, int64(1), slow.Failures)
+	assert.Equal(t, 50*time.Millisecond, slow.Timeout)
+
+	panicking := findCollectorStatus(t, core, "panicking")
+	assert.Contains(t, panicking.LastError, "nil map")
+
+	healthy := findCollectorStatus(t, core, "healthy")
+	assert.Empty(t, healthy.LastError)
+	assert.Equal(t, 10*time.Second, healthy.Timeout)
+
+	// 超时的采集仍未返回时跳过，不重复启动
+	core.Collect()
+	assert.Equal(t, int64(1), findCollectorStatus(t, core, "slow").Failures)
+	assert.Equal(t, int64(2), findCollectorStatus(t, core, "panicking").Failures)
+}
+
+func TestCollectorInterval(t *testing.T) {
+	core := newTestMonitoringCore()
+	hourly := &lifecycleCollector{name: "hourly"}
+	everyRound := &lifecycleCollector{name: "every_round"}
+	require.NoError(t, core.RegisterCollectorWithOptions(hourly, Services.CollectorOptions{Interval: time.Hour}))
+	require.NoError(t, core.RegisterCollector(everyRound))
+
+	for i := 0; i < 3; i++ {
+		core.Collect()
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hourly.collects))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&everyRound.collects))
+	// 未到期的采集器保留上次的指标值
+	assert.Equal(t, 1.0, core.Values()["hourly_collects"])
+}
+
+const nginxStubStatus = `Active connections: 291
+server accepts handled requests
+ 16630948 16630948 31070465
+Reading: 6 Writing: 179 Waiting: 106
+`
+
+func TestNginxStubStatusCollector(t *testing.T) {
+	metrics, err := Services.ParseNginxStubStatus(strings.NewReader(nginxStubStatus))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{
+		"nginx_active_connections": 291,
+		"nginx_accepts":            16630948,
+		"nginx_handled":            16630948,
+		"nginx_requests":           31070465,
+		"nginx_reading":            6,
+		"nginx_writing":            179,
+		"nginx_waiting":            106,
+	}, metrics)
+
+	_, err = Services.ParseNginxStubStatus(strings.NewReader("<html>Welcome to nginx!</html>"))
+	assert.Error(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/nginx_status" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, nginxStubStatus)
+	}))
+	defer server.Close()
+
+	core := newTestMonitoringCore()
+	assert.Error(t, core.RegisterCollector(Services.NewNginxStubStatusCollector("127.0.0.1/nginx_status")))
+	require.NoError(t, core.RegisterCollectorWithOptions(
+		Services.NewNginxStubStatusCollector(server.URL+"/nginx_status"),
+		Services.CollectorOptions{Timeout: time.Second},
+	))
+	require.NoError(t, core.AddRule(&Services.AlertRule{
+		ID: "nginx_connections", Name: "nginx连接数过高", Metric: "nginx_active_connections", Condition: ">", Threshold: 200,
+		Level: Services.AlertLevelWarning, Enabled: true,
+	}))
+
+	require.NoError(t, core.Evaluate())
+	assert.Equal(t, 31070465.0, core.Values()["nginx_requests"])
+	assert.Len(t, core.Alerts("active", 0), 1)
+
+	// 状态页不可用时只记录采集失败
+	require.NoError(t, core.RegisterCollector(Services.NewNginxStubStatusCollector(server.URL+"/missing")))
+	core.Collect()
+	assert.Contains(t, findCollectorStatus(t, core, "nginx").LastError, "404")
+}
@@ -0,0 +1,137 @@
+package Monitoring
+
+import (
+	"cloud-platform-api/app/Services"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// metricValues 可调整的指标取值
+type metricValues map[string]float64
+
+func (m metricValues) provider(metric string) (float64, error) {
+	value, ok := m[metric]
+	if !ok {
+		return 0, fmt.Errorf("未知指标: %s", metric)
+	}
+	return value, nil
+}
+
+func TestParseAlertExpression(t *testing.T) {
+	expr, err := Services.ParseAlertExpression("cpu_usage > 80 AND (error_rate >= 5 || NOT rate(queue_depth) < 10) for 5m")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cpu_usage", "error_rate", "queue_depth"}, expr.Metrics())
+	assert.Equal(t, 5*time.Minute, expr.For)
+	assert.True(t, expr.UsesRate())
+
+	values := map[string]float64{"cpu_usage": 90, "error_rate": 1}
+	lookup := func(fn, metric string) (float64, bool) {
+		if fn == "rate" {
+			return 20, true
+		}
+		value, ok := values[metric]
+		return value, ok
+	}
+	assert.True(t, expr.Evaluate(lookup))
+	values["cpu_usage"] = 70
+	assert.False(t, expr.Evaluate(lookup))
+
+	for _, invalid := range []string{"", "cpu_usage >", "cpu_usage > abc", "(cpu_usage > 1", "cpu_usage = 1", "AND > 1", "cpu_usage > 1 FOR soon", "cpu_usage > 1 extra"} {
+		_, err := Services.ParseAlertExpression(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestCompositeRuleRequiresAllConditions(t *testing.T) {
+	metrics := metricValues{"cpu_usage": 85, "error_rate": 2}
+	service := Services.NewAlertService(nil, nil)
+	service.SetResolutionPolicy(0, time.Minute, 0)
+	service.SetMetricProvider(metrics.provider)
+	require.Error(t, service.AddRule(&Services.AlertRule{ID: "bad", Expression: "cpu_usage >", Enabled: true}))
+	require.NoError(t, service.AddRule(&Services.AlertRule{
+		ID:         "overload",
+		Name:       "过载",
+		Expression: "cpu_usage > 80 AND error_rate > 5",
+		Level:      Services.AlertLevelCritical,
+		Enabled:    true,
+	}))
+
+	require.NoError(t, service.CheckAlerts())
+	assert.Empty(t, service.GetAlerts("active", 10))
+
+	metrics["error_rate"] = 6
+	require.NoError(t, service.CheckAlerts())
+	alerts := service.GetAlerts("active", 10)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "cpu_usage,error_rate", alerts[0].Metric)
+	assert.Contains(t, alerts[0].Message, "cpu_usage=85.00, error_rate=6.00")
+
+	metrics["cpu_usage"] = 50
+	require.NoError(t, service.CheckAlerts())
+	assert.Len(t, service.GetAlerts("resolved", 10), 1)
+}
+
+func TestSustainedDurationEvaluation(t *testing.T) {
+	metrics := metricValues{"cpu_usage": 90, "error_rate": 10}
+	service := Services.NewAlertService(nil, nil)
+	service.SetResolutionPolicy(0, time.Minute, 0)
+	service.SetMetricProvider(metrics.provider)
+	require.NoError(t, service.AddRule(&Services.AlertRule{
+		ID:         "sustained",
+		Expression: "cpu_usage > 80 AND error_rate > 5 FOR 200ms",
+		Enabled:    true,
+	}))
+
+	// 单次满足不触发
+	require.NoError(t, service.CheckAlerts())
+	assert.Empty(t, service.GetAlerts("", 10))
+
+	// 中途不满足时重新计时
+	time.Sleep(120 * time.Millisecond)
+	metrics["error_rate"] = 1
+	require.NoError(t, service.CheckAlerts())
+	metrics["error_rate"] = 10
+	require.NoError(t, service.CheckAlerts())
+	time.Sleep(120 * time.Millisecond)
+	require.NoError(t, service.CheckAlerts())
+	assert.Empty(t, service.GetAlerts("", 10))
+
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, service.CheckAlerts())
+	assert.Len(t, service.GetAlerts("active", 10), 1)
+}
+
+func TestRateOfChangeCondition(t *testing.T) {
+	metrics := metricValues{"queue_depth": 100}
+	service := Services.NewAlertService(nil, nil)
+	service.SetResolutionPolicy(0, time.Minute, 0)
+	service.SetMetricProvider(metrics.provider)
+	require.NoError(t, service.AddRule(&Services.AlertRule{
+		ID:         "queue_growth",
+		Expression: "delta(queue_depth) > 50 OR rate(queue_depth) > 100000",
+		Enabled:    true,
+	}))
+
+	// 只有一个采样时无法计算变化量
+	require.NoError(t, service.CheckAlerts())
+	assert.Empty(t, service.GetAlerts("", 10))
+
+	metrics["queue_depth"] = 120
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, service.CheckAlerts())
+	assert.Empty(t, service.GetAlerts("", 10))
+
+	metrics["queue_depth"] = 200
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, service.CheckAlerts())
+	assert.Len(t, service.GetAlerts("active", 10), 1)
+
+	// 指标不再增长，变化量回落后恢复
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, service.CheckAlerts())
+	assert.Len(t, service.GetAlerts("resolved", 10), 1)
+}
@@ -0,0 +1,288 @@
+package Monitoring
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	dashboardOwner = Services.DashboardViewer{UserID: 1, Role: "user"}
+	dashboardOps   = Services.DashboardViewer{UserID: 2, Role: "ops"}
+	dashboardOther = Services.DashboardViewer{UserID: 3, Role: "user"}
+	dashboardAdmin = Services.DashboardViewer{UserID: 9, Role: "admin"}
+)
+
+func setupDashboardService(t *testing.T) (*Services.MonitoringDashboardService, *Services.MonitoringCore) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "dashboards.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.MonitoringDashboard{}, &Models.MonitoringWidget{}))
+
+	core := newTestMonitoringCore()
+	service := Services.NewMonitoringDashboardService(db)
+	service.SetMonitoringCore(core)
+	return service, core
+}
+
+func TestDashboardVisibilityAndPermissions(t *testing.T) {
+	service, _ := setupDashboardService(t)
+
+	shared, err := service.CreateDashboard(Services.MonitoringDashboardInput{Name: "运维总览", SharedRoles: []string{" ops "}}, dashboardOwner)
+	require.NoError(t, err)
+	assert.Equal(t, "ops", shared.SharedRoles)
+	assert.Equal(t, "{}", shared.Layout)
+	assert.Equal(t, 30, shared.RefreshInterval)
+	_, err = service.CreateDashboard(Services.MonitoringDashboardInput{Name: "公开看板", IsPublic: true}, dashboardOther)
+	require.NoError(t, err)
+
+	names := func(viewer Services.DashboardViewer) []string {
+		dashboards, err := service.ListDashboards(viewer)
+		require.NoError(t, err)
+		result := make([]string, 0, len(dashboards))
+		for _, dashboard := range dashboards {
+			result = append(result, dashboard.Name)
+		}
+		return result
+	}
+	assert.ElementsMatch(t, []string{"运维总览", "公开看板"}, names(dashboardOps))
+	assert.Equal(t, []string{"公开看板"}, names(dashboardOther))
+	assert.Len(t, names(dashboardAdmin), 2)
+
+	// 未共享的用户看不到仪表板，共享角色只能查看不能修改
+	_, err = service.GetDashboard(shared.ID, dashboardOther)
+	assert.ErrorIs(t, err, Services.ErrDashboardNotFound)
+	_, err = service.GetDashboard(shared.ID, dashboardOps)
+	require.NoError(t, err)
+	_, err = service.UpdateDashboard(shared.ID, Services.MonitoringDashboardInput{Name: "改名"}, dashboardOps)
+	assert.ErrorIs(t, err, Services.ErrDashboardForbidden)
+	_, err = service.CreateWidget(shared.ID, Services.MonitoringWidgetInput{Name: "说明", Type: "text"}, dashboardOps)
+	assert.ErrorIs(t, err, Services.ErrDashboardForbidden)
+
+	// 只有管理员可以设置默认仪表板，名称不能重复
+	_, err = service.CreateDashboard(Services.MonitoringDashboardInput{Name: "默认", IsDefault: true}, dashboardOwner)
+	assert.ErrorIs(t, err, Services.ErrDashboardForbidden)
+	_, err = service.CreateDashboard(Services.MonitoringDashboardInput{Name: "公开看板"}, dashboardOwner)
+	assert.ErrorIs(t, err, Services.ErrDashboardNameExists)
+	_, err = service.CreateDashboard(Services.MonitoringDashboardInput{Name: "布局", Layout: json.RawMessage("{")}, dashboardOwner)
+	assert.ErrorIs(t, err, Services.ErrInvalidDashboard)
+
+	updated, err := service.UpdateDashboard(shared.ID, Services.MonitoringDashboardInput{Name: "运维总览", IsDefault: true, Layout: json.RawMessage(`{"columns":12}`)}, dashboardAdmin)
+	require.NoError(t, err)
+	assert.True(t, updated.IsDefault)
+	assert.Equal(t, []string{"运维总览", "公开看板"}, names(dashboardAdmin))
+	assert.Equal(t, "", updated.SharedRoles)
+	assert.Equal(t, []string{"公开看板"}, names(dashboardOps))
+
+	require.NoError(t, service.DeleteDashboard(shared.ID, dashboardOwner))
+	_, err = service.GetDashboard(shared.ID, dashboardAdmin)
+	assert.ErrorIs(t, err, Services.ErrDashboardNotFound)
+}
+
+func TestDashboardWidgetData(t *testing.T) {
+	service, core := setupDashboardService(t)
+	dashboard, err := service.CreateDashboard(Services.MonitoringDashboardInput{Name: "服务器"}, dashboardOwner)
+	require.NoError(t, err)
+
+	thresholds := []Services.WidgetThreshold{{Value: 90, Level: "critical"}, {Value: 70, Level: "warning"}}
+	gauge, err := service.CreateWidget(dashboard.ID, Services.MonitoringWidgetInput{
+		Name:       "CPU",
+		Type:       "gauge",
+		DataSource: Services.WidgetDataSourceMetric,
+		Query:      &Services.WidgetQuery{Metrics: []string{"cpu_usage", "memory_usage", "missing"}},
+		Thresholds: thresholds,
+	}, dashboardOwner)
+	require.NoError(t, err)
+	assert.Equal(t, `{"w":6,"h":4}`, gauge.Size)
+	assert.Equal(t, `[{"value":70,"level":"warning"},{"value":90,"level":"critical"}]`, gauge.Thresholds)
+
+	core.Observe("cpu_usage", 75)
+	core.Observe("memory_usage", 40)
+	data, err := service.WidgetData(dashboard.ID, gauge.ID, dashboardOwner)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"cpu_usage": 75, "memory_usage": 40}, data.Values)
+	assert.Equal(t, map[string]string{"cpu_usage": "warning", "memory_usage": "normal"}, data.States)
+
+	// 查询参数按数据源校验
+	_, err = service.CreateWidget(dashboard.ID, Services.MonitoringWidgetInput{Name: "空", Type: "line", DataSource: Services.WidgetDataSourceMetric}, dashboardOwner)
+	assert.ErrorIs(t, err, Services.ErrInvalidDashboard)
+	_, err = service.CreateWidget(dashboard.ID, Services.MonitoringWidgetInput{Name: "饼图", Type: "pie", DataSource: Services.WidgetDataSourceMetric}, dashboardOwner)
+	assert.ErrorIs(t, err, Services.ErrInvalidDashboard)
+	_, err = service.CreateWidget(dashboard.ID, Services.MonitoringWidgetInput{
+		Name: "范围", Type: "line", DataSource: Services.WidgetDataSourceMetricHistory,
+		Query: &Services.WidgetQuery{Metrics: []string{"cpu_usage"}, Range: "90d"},
+	}, dashboardOwner)
+	assert.ErrorIs(t, err, Services.ErrInvalidDashboard)
+
+	line, err := service.CreateWidget(dashboard.ID, Services.MonitoringWidgetInput{
+		Name:       "CPU趋势",
+		Type:       "line",
+		DataSource: Services.WidgetDataSourceMetricHistory,
+		Query:      &Services.WidgetQuery{Metrics: []string{"cpu_usage"}, Range: "1h", Step: "10m", Aggregation: "max"},
+	}, dashboardOwner)
+	require.NoError(t, err)
+	_, err = service.WidgetData(dashboard.ID, line.ID, dashboardOwner)
+	assert.ErrorIs(t, err, Services.ErrMetricHistoryUnavailable)
+
+	history := setupMetricHistory(t)
+	service.SetMetricHistory(history)
+	now := time.Now()
+	seedMetric(t, history, "cpu_usage", now.Add(-50*time.Minute), now, time.Minute)
+	data, err = service.WidgetData(dashboard.ID, line.ID, dashboardOwner)
+	require.NoError(t, err)
+	require.Len(t, data.Series, 1)
+	assert.GreaterOrEqual(t, len(data.Series[0].Points), 5)
+	assert.LessOrEqual(t, len(data.Series[0].Points), 7)
+	// 中间的分桶包含10个采样，按最大值聚合
+	points := data.Series[0].Points
+	for _, point := range points[1 : len(points)-1] {
+		assert.Equal(t, 52.0, point.Value)
+	}
+	assert.Equal(t, points[len(points)-1].Value, data.Values["cpu_usage"])
+
+	_, err = service.WidgetData(dashboard.ID, gauge.ID+100, dashboardOwner)
+	assert.ErrorIs(t, err, Services.ErrWidgetNotFound)
+	_, err = service.WidgetData(dashboard.ID, gauge.ID, dashboardOther)
+	assert.ErrorIs(t, err, Services.ErrDashboardNotFound)
+}
+
+func TestDashboardExportImport(t *testing.T) {
+	service, _ := setupDashboardService(t)
+	dashboard, err := service.CreateDashboard(Services.MonitoringDashboardInput{
+		Name: "数据库", Layout: json.RawMessage(`{"columns":12}`), SharedRoles: []string{"ops", "dba"},
+	}, dashboardAdmin)
+	require.NoError(t, err)
+	_, err = service.CreateWidget(dashboard.ID, Services.MonitoringWidgetInput{
+		Name: "连接数", Type: "stat", DataSource: Services.WidgetDataSourceMetric,
+		Query:    &Services.WidgetQuery{Metrics: []string{"db_connections"}},
+		Position: Services.WidgetPosition{X: 6}, Config: json.RawMessage(`{"unit":"个"}`),
+	}, dashboardAdmin)
+	require.NoError(t, err)
+	_, err = service.CreateWidget(dashboard.ID, Services.MonitoringWidgetInput{Name: "说明", Type: "text"}, dashboardAdmin)
+	require.NoError(t, err)
+
+	export, err := service.ExportDashboard(dashboard.ID, dashboardOps)
+	require.NoError(t, err)
+	assert.Equal(t, 1, export.Version)
+	assert.Equal(t, []string{"ops", "dba"}, export.Dashboard.SharedRoles)
+	require.Len(t, export.Widgets, 2)
+
+	// 经过JSON往返后导入，同名时需要指定新名称
+	raw, err := json.Marshal(export)
+	require.NoError(t, err)
+	var decoded Services.MonitoringDashboardExport
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	_, err = service.ImportDashboard(decoded, "", dashboardOwner)
+	assert.ErrorIs(t, err, Services.ErrDashboardNameExists)
+
+	imported, err := service.ImportDashboard(decoded, "数据库副本", dashboardOwner)
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), imported.CreatedBy)
+	copied, err := service.GetDashboard(imported.ID, dashboardOwner)
+	require.NoError(t, err)
+	require.Len(t, copied.Widgets, 2)
+	assert.Equal(t, `{"columns":12}`, copied.Layout)
+	assert.Equal(t, `{"x":6,"y":0}`, copied.Widgets[0].Position)
+	assert.Equal(t, `{"unit":"个"}`, copied.Widgets[0].Config)
+
+	decoded.Version = 2
+	_, err = service.ImportDashboard(decoded, "新版本", dashboardOwner)
+	assert.ErrorIs(t, err, Services.ErrInvalidDashboard)
+}
+
+func TestDashboardEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service, core := setupDashboardService(t)
+	controller := Controllers.NewMonitoringDashboardController(service)
+
+	router := gin.New()
+	router.Use(func(ctx *gin.Context) {
+		if userID := ctx.GetHeader("X-Test-User"); userID != "" {
+			ctx.Set("user_id", userID)
+			ctx.Set("user_role", ctx.GetHeader("X-Test-Role"))
+		}
+		ctx.Next()
+	})
+	router.GET("/dashboards", controller.GetDashboards)
+	router.POST("/dashboards", controller.CreateDashboard)
+	router.POST("/dashboards/import", controller.ImportDashboard)
+	router.PUT("/dashboards/:id", controller.UpdateDashboard)
+	router.GET("/dashboards/:id/export", controller.ExportDashboard)
+	router.POST("/dashboards/:id/widgets", controller.CreateWidget)
+	router.GET("/dashboards/:id/widgets/:widget_id/data", controller.GetWidgetData)
+
+	request := func(method, path, user, role string, body interface{}) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		if user != "" {
+			req.Header.Set("X-Test-User", user)
+			req.Header.Set("X-Test-Role", role)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodGet, "/dashboards", "", "", nil).Code)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/dashboards", "1", "user", map[string]interface{}{}).Code)
+
+	w := request(http.MethodPost, "/dashboards", "1", "user", map[string]interface{}{"name": "接口", "shared_roles": []string{"ops"}})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusConflict, request(http.MethodPost, "/dashboards", "1", "user", map[string]interface{}{"name": "接口"}).Code)
+	assert.Equal(t, http.StatusForbidden, request(http.MethodPut, "/dashboards/1", "2", "ops", map[string]interface{}{"name": "改名"}).Code)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPut, "/dashboards/abc", "1", "user", map[string]interface{}{"name": "改名"}).Code)
+
+	w = request(http.MethodPost, "/dashboards/1/widgets", "1", "user", map[string]interface{}{
+		"name": "队列", "type": "stat", "data_source": "metric",
+		"query": map[string]interface{}{"metrics": []string{"queue_depth"}},
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/dashboards/1/widgets", "1", "user", map[string]interface{}{"name": "错误", "type": "stat"}).Code)
+
+	core.Observe("queue_depth", 12)
+	w = request(http.MethodGet, "/dashboards/1/widgets/1/data", "2", "ops", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var dataResp struct {
+		Data Services.WidgetData `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &dataResp))
+	assert.Equal(t, 12.0, dataResp.Data.Values["queue_depth"])
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/dashboards/1/widgets/1/data", "3", "user", nil).Code)
+
+	// 导出内容可以直接作为导入请求体
+	w = request(http.MethodGet, "/dashboards/1/export", "1", "user", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "dashboard-1.json")
+	var export Services.MonitoringDashboardExport
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &export))
+	w = request(http.MethodPost, "/dashboards/import?name=%E5%AF%BC%E5%85%A5", "3", "user", export)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = request(http.MethodGet, "/dashboards", "3", "user", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var listResp struct {
+		Data []Models.MonitoringDashboard `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listResp))
+	require.Len(t, listResp.Data, 1)
+	assert.Equal(t, "导入", listResp.Data[0].Name)
+}
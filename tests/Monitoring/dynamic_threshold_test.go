@@ -0,0 +1,194 @@
+package Monitoring
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupMetricHistory(t *testing.T) *Services.MetricHistoryService {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "metrics.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.MetricSample{}))
+
+	config := &Config.MonitoringConfig{}
+	config.SetDefaults()
+	return Services.NewMetricHistoryService(db, config)
+}
+
+// seedMetric 从 from 开始每隔 step 写入一个采样，取值在48和52之间交替（均值50，标准差2）
+func seedMetric(t *testing.T, history *Services.MetricHistoryService, name string, from, to time.Time, step time.Duration) {
+	var samples []Models.MetricSample
+	for at, i := from, 0; !at.After(to); at, i = at.Add(step), i+1 {
+		value := 48.0
+		if i%2 == 1 {
+			value = 52
+		}
+		samples = append(samples, Models.MetricSample{Name: name, Value: value, Timestamp: at})
+	}
+	require.NoError(t, history.RecordBatch(samples))
+}
+
+func TestDynamicThresholdUsesSameHourLastWeek(t *testing.T) {
+	history := setupMetricHistory(t)
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour)
+	seedMetric(t, history, "request_latency", lastWeek.Add(-30*time.Minute), lastWeek.Add(30*time.Minute), 5*time.Minute)
+
+	metrics := metricValues{"request_latency": 55}
+	service := Services.NewAlertService(nil, nil)
+	service.SetResolutionPolicy(0, time.Minute, 0)
+	service.SetMetricProvider(metrics.provider)
+	service.SetMetricHistory(history)
+
+	require.Error(t, service.AddRule(&Services.AlertRule{ID: "bad", Type: "adaptive", Metric: "request_latency"}))
+	require.Error(t, service.AddRule(&Services.AlertRule{ID: "bad", Type: Services.AlertRuleTypeDynamic, Expression: "request_latency > 1"}))
+	rule := &Services.AlertRule{
+		ID:      "latency_anomaly",
+		Name:    "延迟异常",
+		Metric:  "request_latency",
+		Type:    Services.AlertRuleTypeDynamic,
+		Level:   Services.AlertLevelWarning,
+		Enabled: true,
+	}
+	require.NoError(t, service.AddRule(rule))
+	require.NotNil(t, rule.Dynamic)
+	assert.Equal(t, 3.0, rule.Dynamic.Sensitivity)
+	assert.Equal(t, 7*24*time.Hour, rule.Dynamic.Seasonality)
+
+	// 默认3倍标准差，上界为56
+	require.NoError(t, service.CheckAlerts())
+	assert.Empty(t, service.GetAlerts("", 10))
+
+	metrics["request_latency"] = 60
+	require.NoError(t, service.CheckAlerts())
+	alerts := service.GetAlerts("active", 10)
+	require.Len(t, alerts, 1)
+	assert.InDelta(t, 56, alerts[0].Threshold, 0.01)
+	assert.Contains(t, alerts[0].Message, "动态阈值")
+
+	// 提高敏感度后较小的偏离也会触发
+	metrics["request_latency"] = 55
+	require.NoError(t, service.CheckAlerts())
+	assert.Len(t, service.GetAlerts("resolved", 10), 1)
+	rule.Dynamic.Sensitivity = 1
+	require.NoError(t, service.CheckAlerts())
+	assert.Len(t, service.GetAlerts("active", 10), 1)
+}
+
+func TestDynamicThresholdWithoutHistoryNeverFires(t *testing.T) {
+	metrics := metricValues{"request_latency": 1000}
+	service := Services.NewAlertService(nil, nil)
+	service.SetMetricProvider(metrics.provider)
+	service.SetMetricHistory(setupMetricHistory(t))
+	require.NoError(t, service.AddRule(&Services.AlertRule{
+		ID:      "latency_anomaly",
+		Metric:  "request_latency",
+		Type:    Services.AlertRuleTypeDynamic,
+		Enabled: true,
+	}))
+
+	require.NoError(t, service.CheckAlerts())
+	assert.Empty(t, service.GetAlerts("", 10))
+}
+
+func TestBacktestDynamicRule(t *testing.T) {
+	history := setupMetricHistory(t)
+	now := time.Now().Truncate(time.Minute)
+	seedMetric(t, history, "queue_depth", now.Add(-10*24*time.Hour), now, 10*time.Minute)
+	spike := now.Add(-2 * 24 * time.Hour)
+	require.NoError(t, history.RecordBatch([]Models.MetricSample{
+		{Name: "queue_depth", Value: 90, Timestamp: spike.Add(time.Minute)},
+		{Name: "queue_depth", Value: 100, Timestamp: spike.Add(2 * time.Minute)},
+	}))
+
+	service := Services.NewAlertService(nil, nil)
+	_, err := service.BacktestRule(&Services.AlertRule{Metric: "queue_depth"}, now.Add(-time.Hour), now)
+	assert.ErrorIs(t, err, Services.ErrMetricHistoryUnavailable)
+
+	service.SetMetricHistory(history)
+	result, err := service.BacktestRule(&Services.AlertRule{
+		Metric:  "queue_depth",
+		Type:    Services.AlertRuleTypeDynamic,
+		Dynamic: &Services.DynamicThreshold{MinSamples: 5},
+	}, now.Add(-30*24*time.Hour), now)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, result.FiringCount)
+	firing := result.Firings[0]
+	assert.True(t, firing.StartedAt.Equal(spike.Add(time.Minute)))
+	require.NotNil(t, firing.EndedAt)
+	assert.Equal(t, 100.0, firing.MaxValue)
+	assert.InDelta(t, 56, firing.Threshold, 0.5)
+	// 前7天没有上周数据，无法计算阈值
+	assert.Less(t, result.Evaluated, result.Samples)
+
+	// 固定阈值规则按持续时间回放
+	result, err = service.BacktestRule(&Services.AlertRule{
+		Metric:    "queue_depth",
+		Condition: ">",
+		Threshold: 80,
+		Duration:  time.Minute,
+	}, now.Add(-30*24*time.Hour), now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.FiringCount)
+	assert.Equal(t, result.Samples, result.Evaluated)
+}
+
+func TestBacktestAlertRuleEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	history := setupMetricHistory(t)
+	now := time.Now()
+	seedMetric(t, history, "queue_depth", now.Add(-10*24*time.Hour), now, 10*time.Minute)
+
+	controller := Controllers.NewMonitoringController()
+	router := gin.New()
+	router.POST("/alert-rules/backtest", controller.BacktestAlertRule)
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/alert-rules/backtest", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	service := Services.NewAlertService(nil, nil)
+	controller.SetAlertService(service)
+	assert.Equal(t, http.StatusServiceUnavailable, post(`{"metric":"queue_depth","type":"dynamic"}`).Code)
+
+	service.SetMetricHistory(history)
+	assert.Equal(t, http.StatusBadRequest, post(`{"type":"dynamic"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"metric":"queue_depth","type":"dynamic","window":"soon"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"metric":"queue_depth","type":"dynamic","days":365}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"metric":"queue_depth","threshold":80}`).Code)
+
+	w := post(`{"metric":"queue_depth","type":"dynamic","sensitivity":2,"window":"2h","min_samples":5,"days":7}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Data struct {
+			Rule   Services.AlertRule           `json:"rule"`
+			Result Services.AlertBacktestResult `json:"result"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 2.0, resp.Data.Rule.Dynamic.Sensitivity)
+	assert.Equal(t, 2*time.Hour, resp.Data.Rule.Dynamic.Window)
+	assert.Greater(t, resp.Data.Result.Samples, 0)
+	assert.Equal(t, 0, resp.Data.Result.FiringCount)
+}
@@ -0,0 +1,262 @@
+package Monitoring
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupIncidentService(t *testing.T, reopenWindow time.Duration) (*Services.MonitoringIncidentService, *Services.MonitoringCore, *float64) {
+	service, core, depth, _ := setupIncidentServiceWithDB(t, reopenWindow)
+	return service, core, depth
+}
+
+func setupIncidentServiceWithDB(t *testing.T, reopenWindow time.Duration) (*Services.MonitoringIncidentService, *Services.MonitoringCore, *float64, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "incidents.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.MonitoringIncident{}, &Models.MonitoringIncidentEvent{},
+		&Models.User{}, &Models.Comment{}, &Models.AuditLog{}, &Models.Notification{}))
+
+	config := &Config.MonitoringConfig{}
+	config.SetDefaults()
+	config.Incidents.ReopenWindow = reopenWindow
+
+	core := newTestMonitoringCore()
+	depth := 0.0
+	require.NoError(t, core.RegisterCollector(Services.NewMetricCollector("queue", func(ctx context.Context) (map[string]float64, error) {
+		return map[string]float64{"queue_depth": depth}, nil
+	})))
+	require.NoError(t, core.AddRule(&Services.AlertRule{
+		ID: "queue_backlog", Name: "队列积压", Metric: "queue_depth", Condition: ">", Threshold: 10,
+		Level: Services.AlertLevelWarning, Enabled: true,
+	}))
+	require.NoError(t, core.AddRule(&Services.AlertRule{
+		ID: "queue_critical", Name: "队列严重积压", Metric: "queue_depth", Condition: ">", Threshold: 100,
+		Level: Services.AlertLevelCritical, Enabled: true,
+	}))
+
+	service := Services.NewMonitoringIncidentService(db, config)
+	service.Attach(core)
+	return service, core, &depth, db
+}
+
+func timelineTypes(timeline []Models.MonitoringIncidentEvent) []string {
+	types := make([]string, 0, len(timeline))
+	for _, event := range timeline {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+func TestIncidentLifecycleFromAlertTransitions(t *testing.T) {
+	service, core, depth := setupIncidentService(t, time.Hour)
+
+	*depth = 20
+	require.NoError(t, core.Evaluate())
+	// 同一告警重复触发只累加告警次数，不记录到事件
+	require.NoError(t, core.Evaluate())
+
+	detail, err := service.GetIncident(1)
+	require.NoError(t, err)
+	assert.Equal(t, "queue_backlog", detail.RuleID)
+	assert.Equal(t, Services.IncidentStatusOpen, detail.Status)
+	assert.Equal(t, "warning", detail.Severity)
+	assert.Equal(t, 1, detail.AlertCount)
+	assert.Equal(t, 1, detail.ActiveAlerts)
+	assert.Equal(t, []string{"opened", "alert_triggered"}, timelineTypes(detail.Timeline))
+
+	detail, err = service.Acknowledge(1, 7, "正在排查消费者")
+	require.NoError(t, err)
+	assert.Equal(t, Services.IncidentStatusAcknowledged, detail.Status)
+	require.NotNil(t, detail.AcknowledgedBy)
+	assert.Equal(t, uint(7), *detail.AcknowledgedBy)
+	_, err = service.Acknowledge(1, 7, "")
+	assert.ErrorIs(t, err, Services.ErrIncidentAcknowledged)
+
+	_, err = service.AddComment(1, 7, "  ")
+	assert.ErrorIs(t, err, Services.ErrInvalidIncidentComment)
+	comment, err := service.AddComment(1, 8, "消费者实例OOM，已扩容")
+	require.NoError(t, err)
+	assert.Equal(t, Services.IncidentEventComment, comment.Type)
+
+	// 告警全部恢复后自动解决
+	*depth = 1
+	require.NoError(t, core.Evaluate())
+	detail, err = service.GetIncident(1)
+	require.NoError(t, err)
+	assert.Equal(t, Services.IncidentStatusResolved, detail.Status)
+	assert.Nil(t, detail.ResolvedBy)
+	assert.Equal(t, 0, detail.ActiveAlerts)
+	assert.Equal(t, []string{"opened", "alert_triggered", "acknowledged", "comment", "alert_resolved", "resolved"}, timelineTypes(detail.Timeline))
+
+	// 已解决的事件只能补充解决说明
+	_, err = service.Resolve(1, 7, "")
+	assert.ErrorIs(t, err, Services.ErrIncidentResolved)
+	detail, err = service.Resolve(1, 7, "增加消费者内存限制")
+	require.NoError(t, err)
+	assert.Equal(t, "增加消费者内存限制", detail.ResolutionNote)
+	assert.Nil(t, detail.ResolvedBy, "自动解决的事件不改变解决者")
+
+	// 重新打开窗口内再次触发时重新打开原事件，更严重的告警提升事件严重程度
+	*depth = 200
+	require.NoError(t, core.Evaluate())
+	detail, err = service.GetIncident(1)
+	require.NoError(t, err)
+	assert.Equal(t, Services.IncidentStatusAcknowledged, detail.Status, "重新打开后保留首次确认")
+	assert.Equal(t, 1, detail.ReopenCount)
+	assert.Equal(t, 2, detail.AlertCount)
+
+	critical, err := service.GetIncident(2)
+	require.NoError(t, err)
+	assert.Equal(t, "queue_critical", critical.RuleID)
+	assert.Equal(t, "critical", critical.Severity)
+
+	detail, err = service.Resolve(2, 7, "")
+	require.NoError(t, err)
+	require.NotNil(t, detail.ResolvedBy)
+	_, err = service.Acknowledge(2, 7, "")
+	assert.ErrorIs(t, err, Services.ErrIncidentResolved)
+	_, err = service.GetIncident(99)
+	assert.ErrorIs(t, err, Services.ErrIncidentNotFound)
+
+	stats, err := service.Stats(time.Now().Add(-time.Hour), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Total)
+	assert.Equal(t, 1, stats.Acknowledged)
+	assert.Equal(t, 1, stats.Resolved)
+	assert.Equal(t, 1, stats.Reopened)
+	assert.Equal(t, map[string]int{"warning": 1, "critical": 1}, stats.BySeverity)
+	require.NotNil(t, stats.MTTA)
+	require.NotNil(t, stats.MTTR)
+	assert.GreaterOrEqual(t, *stats.MTTR, 0.0)
+}
+
+func TestIncidentNotReopenedOutsideWindow(t *testing.T) {
+	service, core, depth := setupIncidentService(t, 0)
+
+	*depth = 20
+	require.NoError(t, core.Evaluate())
+	*depth = 1
+	require.NoError(t, core.Evaluate())
+	*depth = 20
+	require.NoError(t, core.Evaluate())
+
+	first, err := service.GetIncident(1)
+	require.NoError(t, err)
+	assert.Equal(t, Services.IncidentStatusResolved, first.Status)
+	second, err := service.GetIncident(2)
+	require.NoError(t, err)
+	assert.Equal(t, Services.IncidentStatusOpen, second.Status)
+	assert.Equal(t, "queue_backlog", second.RuleID)
+}
+
+func TestIncidentReviewExport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service, core, depth := setupIncidentService(t, time.Hour)
+	*depth = 20
+	require.NoError(t, core.Evaluate())
+	_, err := service.AddComment(1, 3, "消费者| 延迟升高\n开始排查")
+	require.NoError(t, err)
+
+	review, err := service.Review(1)
+	require.NoError(t, err)
+	assert.Nil(t, review.TimeToResolve)
+	assert.Len(t, review.Alerts, 1)
+
+	controller := Controllers.NewMonitoringIncidentController(service)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", "5")
+		c.Set("user_role", "admin")
+	})
+	router.GET("/incidents/:id/review", controller.GetIncidentReview)
+	router.POST("/incidents/:id/resolve", controller.ResolveIncident)
+
+	body, _ := json.Marshal(map[string]string{"message": "扩容消费者"})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/incidents/1/resolve", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/incidents/1/review?format=markdown", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "incident-1-review.md")
+	markdown := w.Body.String()
+	assert.True(t, strings.HasPrefix(markdown, "# 事件复盘 #1: "))
+	assert.Contains(t, markdown, "扩容消费者")
+	assert.Contains(t, markdown, `消费者\| 延迟升高<br>开始排查`)
+	assert.Contains(t, markdown, "| 解决 |")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/incidents/1/review", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data struct {
+			Status        string                           `json:"status"`
+			TimeToResolve *float64                         `json:"time_to_resolve_seconds"`
+			Timeline      []Models.MonitoringIncidentEvent `json:"timeline"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "resolved", resp.Data.Status)
+	assert.NotNil(t, resp.Data.TimeToResolve)
+	assert.Len(t, resp.Data.Timeline, 4)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/incidents/1/review?format=pdf", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/incidents/42/review", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestIncidentReviewIncludesAlertComments(t *testing.T) {
+	service, core, depth, db := setupIncidentServiceWithDB(t, time.Hour)
+	user := Models.User{Username: "oncall", Email: "oncall@example.com", Password: "x", Status: 1}
+	require.NoError(t, db.Create(&user).Error)
+	comments := Services.NewCommentService(db)
+	comments.SetMonitoringCore(core)
+	service.SetCommentService(comments)
+
+	actor := Services.CommentActor{UserID: user.ID}
+	_, err := comments.Create(actor, Models.CommentResourceAlert, "missing", nil, "不存在的告警")
+	assert.ErrorIs(t, err, Services.ErrCommentResourceNotFound)
+
+	*depth = 20
+	require.NoError(t, core.Evaluate())
+	alerts := core.Alerts("active", 0)
+	require.Len(t, alerts, 1)
+	_, err = comments.Create(actor, Models.CommentResourceAlert, alerts[0].ID, nil, "消费者积压| 已扩容")
+	require.NoError(t, err)
+	deleted, err := comments.Create(actor, Models.CommentResourceAlert, alerts[0].ID, nil, "误报")
+	require.NoError(t, err)
+	require.NoError(t, comments.Delete(deleted.ID, actor))
+
+	review, err := service.Review(1)
+	require.NoError(t, err)
+	require.Len(t, review.AlertComments, 1, "已删除的评论不导出")
+	assert.Equal(t, alerts[0].ID, review.AlertComments[0].ResourceID)
+	markdown := review.Markdown()
+	assert.Contains(t, markdown, "## 告警评论")
+	assert.Contains(t, markdown, `| oncall | 消费者积压\| 已扩容 |`)
+	assert.NotContains(t, markdown, "误报")
+}
@@ -0,0 +1,195 @@
+package Monitoring
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Services"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestKubernetesIntegration 创建使用临时服务账号目录的Kubernetes集成
+func newTestKubernetesIntegration(t *testing.T, gateType, externalMetrics string) *Services.KubernetesIntegration {
+	serviceAccount := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(serviceAccount, "namespace"), []byte("production\n"), 0o600))
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	t.Setenv("POD_NAME", "api-7d9f8-xk2lp")
+	t.Setenv("POD_NAMESPACE", "")
+	t.Setenv("NODE_NAME", "node-1")
+	t.Setenv("POD_IP", "10.0.3.17")
+
+	config := &Config.MonitoringConfig{}
+	config.SetDefaults()
+	config.Kubernetes.Enabled = true
+	config.Kubernetes.ServiceAccountPath = serviceAccount
+	config.Kubernetes.ReadinessGateType = gateType
+	config.Kubernetes.ExternalMetrics = externalMetrics
+	require.NoError(t, config.Validate())
+	return Services.NewKubernetesIntegration(config)
+}
+
+func TestKubernetesMetadataDetection(t *testing.T) {
+	integration := newTestKubernetesIntegration(t, "", "")
+	metadata := integration.Metadata()
+	assert.False(t, metadata.InCluster)
+	assert.Equal(t, "api-7d9f8-xk2lp", metadata.PodName)
+	assert.Equal(t, "production", metadata.Namespace, "未注入POD_NAMESPACE时读取服务账号目录")
+	assert.Equal(t, "10.0.3.17", metadata.PodIP)
+	assert.Equal(t, map[string]string{"pod": "api-7d9f8-xk2lp", "namespace": "production", "node": "node-1"}, integration.Labels())
+
+	t.Setenv("POD_NAMESPACE", "staging")
+	assert.Equal(t, "staging", Services.DetectKubernetesMetadata("").Namespace)
+
+	// 部署标签作为默认标签附加到指标，调用方传入的同名标签优先
+	monitoringService := Services.NewOptimizedMonitoringService()
+	monitoringService.SetDefaultTags(integration.Labels())
+	tags := monitoringService.DefaultTags()
+	tags["pod"] = "changed"
+	assert.Equal(t, "api-7d9f8-xk2lp", monitoringService.DefaultTags()["pod"], "DefaultTags 返回副本")
+}
+
+func TestKubernetesReadinessGateSync(t *testing.T) {
+	var mu sync.Mutex
+	var requests []map[string]interface{}
+	var paths, contentTypes, tokens []string
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var patch map[string]interface{}
+		json.Unmarshal(body, &patch)
+		mu.Lock()
+		requests = append(requests, patch)
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		contentTypes = append(contentTypes, r.Header.Get("Content-Type"))
+		tokens = append(tokens, r.Header.Get("Authorization"))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"kind":"Pod"}`))
+	}))
+	defer apiServer.Close()
+
+	integration := newTestKubernetesIntegration(t, "cloud-platform.io/ready", "")
+	// 未连接API Server时无法同步
+	assert.Error(t, integration.SyncReadinessGate(context.Background()))
+
+	integration.SetAPIClient(apiServer.URL, "test-token", apiServer.Client())
+	var probeErr error
+	integration.SetReadinessProbe(func() error { return probeErr })
+
+	require.NoError(t, integration.SyncReadinessGate(context.Background()))
+	// 状态未变化时不重复写入
+	require.NoError(t, integration.SyncReadinessGate(context.Background()))
+	probeErr = errors.New("Service database is not ready")
+	require.NoError(t, integration.SyncReadinessGate(context.Background()))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, requests, 2)
+	assert.Equal(t, "PATCH /api/v1/namespaces/production/pods/api-7d9f8-xk2lp/status", paths[0])
+	assert.Equal(t, "application/strategic-merge-patch+json", contentTypes[0])
+	assert.Equal(t, "Bearer test-token", tokens[0])
+
+	condition := requests[0]["status"].(map[string]interface{})["conditions"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "cloud-platform.io/ready", condition["type"])
+	assert.Equal(t, "True", condition["status"])
+	condition = requests[1]["status"].(map[string]interface{})["conditions"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "False", condition["status"])
+	assert.Equal(t, "Service database is not ready", condition["message"])
+
+	status := integration.ReadinessGateStatus()
+	assert.Equal(t, "False", status.Status)
+	assert.Empty(t, status.LastError)
+}
+
+func TestKubernetesExternalMetricsAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	integration := newTestKubernetesIntegration(t, "", "queue_depth")
+	core := newTestMonitoringCore()
+	core.Observe(`queue_depth{queue="emails"}`, 42)
+	core.Observe(`queue_depth{queue="reports"}`, 2.5)
+	core.Observe("cpu_usage", 80)
+	integration.SetMonitoringCore(core)
+
+	controller := Controllers.NewKubernetesController(integration)
+	router := gin.New()
+	router.GET("/apis/external.metrics.k8s.io/v1beta1", controller.GetExternalMetricResources)
+	router.GET("/apis/external.metrics.k8s.io/v1beta1/namespaces/:namespace/:metric", controller.GetExternalMetric)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := get("/apis/external.metrics.k8s.io/v1beta1")
+	require.Equal(t, http.StatusOK, w.Code)
+	var resources struct {
+		Kind         string `json:"kind"`
+		GroupVersion string `json:"groupVersion"`
+		Resources    []struct {
+			Name       string `json:"name"`
+			Namespaced bool   `json:"namespaced"`
+		} `json:"resources"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resources))
+	assert.Equal(t, "APIResourceList", resources.Kind)
+	assert.Equal(t, "external.metrics.k8s.io/v1beta1", resources.GroupVersion)
+	require.Len(t, resources.Resources, 1)
+	assert.Equal(t, "queue_depth", resources.Resources[0].Name)
+
+	type valueList struct {
+		Kind  string                         `json:"kind"`
+		Items []Services.ExternalMetricValue `json:"items"`
+	}
+	w = get("/apis/external.metrics.k8s.io/v1beta1/namespaces/production/queue_depth")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list valueList
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, "ExternalMetricValueList", list.Kind)
+	require.Len(t, list.Items, 2)
+	assert.Equal(t, map[string]string{"queue": "emails"}, list.Items[0].MetricLabels)
+	assert.Equal(t, "42", list.Items[0].Value)
+	assert.Equal(t, "2500m", list.Items[1].Value)
+
+	w = get("/apis/external.metrics.k8s.io/v1beta1/namespaces/production/queue_depth?labelSelector=queue%3Demails")
+	require.Equal(t, http.StatusOK, w.Code)
+	list = valueList{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, "queue_depth", list.Items[0].MetricName)
+
+	w = get("/apis/external.metrics.k8s.io/v1beta1/namespaces/production/queue_depth?labelSelector=queue+in+(emails)")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 未允许的指标和其他命名空间的查询视为不存在
+	w = get("/apis/external.metrics.k8s.io/v1beta1/namespaces/production/cpu_usage")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), `"kind":"Status"`)
+	w = get("/apis/external.metrics.k8s.io/v1beta1/namespaces/default/queue_depth")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestKubernetesConfigValidation(t *testing.T) {
+	config := &Config.MonitoringConfig{}
+	config.SetDefaults()
+	config.Kubernetes.Enabled = true
+	require.NoError(t, config.Validate())
+
+	config.Kubernetes.ExternalMetrics = "queue_depth, bad-name"
+	assert.Error(t, config.Validate())
+	config.Kubernetes.ExternalMetrics = "queue_depth"
+	config.Kubernetes.ReadinessGateType = "cloud-platform.io/ready"
+	config.Kubernetes.ReadinessSyncInterval = 0
+	assert.Error(t, config.Validate())
+}
@@ -0,0 +1,147 @@
+package Monitoring
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMetricSink 记录写入批次的存储，前 failures 次写入返回错误
+type fakeMetricSink struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+	batches  [][]Models.MetricSample
+}
+
+func (s *fakeMetricSink) RecordBatch(samples []Models.MetricSample) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("database is locked")
+	}
+	s.batches = append(s.batches, append([]Models.MetricSample(nil), samples...))
+	return nil
+}
+
+func (s *fakeMetricSink) batchSizes() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sizes := make([]int, 0, len(s.batches))
+	for _, batch := range s.batches {
+		sizes = append(sizes, len(batch))
+	}
+	return sizes
+}
+
+// newTestMetricBatchWriter 创建使用较小批量和退避时间的写入器
+func newTestMetricBatchWriter(t *testing.T, sink Services.MetricSampleSink, size, maxQueue, maxRetries int) *Services.MetricBatchWriter {
+	config := &Config.MonitoringConfig{}
+	config.SetDefaults()
+	config.StorageConfig.Batch.Size = size
+	config.StorageConfig.Batch.MaxQueue = maxQueue
+	config.StorageConfig.Batch.MaxRetries = maxRetries
+	config.StorageConfig.Batch.RetryBackoff = time.Millisecond
+	config.StorageConfig.Batch.FlushInterval = time.Hour
+	require.NoError(t, config.Validate())
+	return Services.NewMetricBatchWriter(sink, config)
+}
+
+// testMetricSamples 生成 n 个采样
+func testMetricSamples(n int) []Models.MetricSample {
+	now := time.Now()
+	samples := make([]Models.MetricSample, 0, n)
+	for i := 0; i < n; i++ {
+		samples = append(samples, Models.MetricSample{Name: fmt.Sprintf("queue_depth_%d", i%3), Value: float64(i), Timestamp: now})
+	}
+	return samples
+}
+
+func TestMetricBatchWriterBatchesAndRetries(t *testing.T) {
+	sink := &fakeMetricSink{failures: 1}
+	writer := newTestMetricBatchWriter(t, sink, 4, 100, 2)
+	core := newTestMonitoringCore()
+	writer.SetMonitoringCore(core)
+
+	// Write 不直接访问存储
+	writer.Write(testMetricSamples(10))
+	assert.Equal(t, 0, sink.calls)
+	assert.Equal(t, 10, writer.Stats().Queued)
+
+	require.NoError(t, writer.Flush())
+	assert.Equal(t, []int{4, 4, 2}, sink.batchSizes())
+	stats := writer.Stats()
+	assert.Equal(t, int64(10), stats.Written)
+	assert.Equal(t, int64(3), stats.Batches)
+	assert.Equal(t, int64(1), stats.Retries, "第一批失败一次后重试成功")
+	assert.Equal(t, int64(0), stats.Failures)
+	assert.Equal(t, 0, stats.Queued)
+
+	values := core.Values()
+	assert.Equal(t, 10.0, values["metric_write_samples_total"])
+	assert.Equal(t, 0.0, values["metric_write_queue_size"])
+	assert.Equal(t, 0.0, values["metric_write_dropped_total"])
+}
+
+func TestMetricBatchWriterRequeuesAndDrops(t *testing.T) {
+	sink := &fakeMetricSink{failures: 2}
+	writer := newTestMetricBatchWriter(t, sink, 4, 6, 1)
+	core := newTestMonitoringCore()
+	writer.SetMonitoringCore(core)
+
+	// 队列上限为6，超出的采样丢弃并计数
+	writer.Write(testMetricSamples(8))
+	assert.Equal(t, 6, writer.Stats().Queued)
+	assert.Equal(t, int64(2), writer.Stats().Dropped)
+
+	// 重试耗尽后放回队列，等待下次刷新
+	assert.Error(t, writer.Flush())
+	stats := writer.Stats()
+	assert.Equal(t, 6, stats.Queued)
+	assert.Equal(t, int64(1), stats.Failures)
+	assert.Equal(t, "database is locked", stats.LastError)
+	assert.Equal(t, 1.0, core.Values()["metric_write_failures_total"])
+	assert.Equal(t, 2.0, core.Values()["metric_write_dropped_total"])
+
+	require.NoError(t, writer.Close())
+	assert.Equal(t, []int{4, 2}, sink.batchSizes())
+	assert.Equal(t, []float64{0, 1, 2, 3}, sampleValues(sink.batches[0]), "放回的批次保持原有顺序")
+	assert.Empty(t, writer.Stats().LastError)
+}
+
+func TestMetricBatchWriterRedisFallback(t *testing.T) {
+	sink := &fakeMetricSink{}
+	writer := newTestMetricBatchWriter(t, sink, 10, 100, 0)
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", DialTimeout: 50 * time.Millisecond, MaxRetries: -1})
+	defer client.Close()
+	writer.SetRedisWriteBehind(client, "monitoring:metric_write_queue")
+	assert.True(t, writer.Stats().WriteBehind)
+
+	// Redis不可用时退回内存队列，采样不丢失
+	writer.Write(testMetricSamples(3))
+	assert.Equal(t, 3, writer.Stats().Queued)
+
+	assert.Error(t, writer.Flush(), "内存队列写入成功，读取Redis队列失败")
+	assert.Equal(t, []int{3}, sink.batchSizes())
+	assert.Equal(t, 0, writer.Stats().Queued)
+}
+
+// sampleValues 返回采样值列表
+func sampleValues(samples []Models.MetricSample) []float64 {
+	result := make([]float64, 0, len(samples))
+	for _, sample := range samples {
+		result = append(result, sample.Value)
+	}
+	return result
+}
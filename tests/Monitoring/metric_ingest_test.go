@@ -0,0 +1,233 @@
+package Monitoring
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Services"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestParseInfluxLineProtocol(t *testing.T) {
+	body := `# 批处理任务指标
+job,name=billing,region=cn\ east duration=12.5,rows=300i,ok=true,status="done" 1700000000000
+queue_depth value=7
+`
+	samples, err := Services.ParseInfluxLineProtocol([]byte(body), "ms")
+	require.NoError(t, err)
+	require.Len(t, samples, 4)
+
+	labels := map[string]string{"name": "billing", "region": "cn east"}
+	assert.Equal(t, Services.IngestSample{Name: "job_duration", Value: 12.5, Labels: labels, Timestamp: time.UnixMilli(1700000000000)}, samples[0])
+	assert.Equal(t, 300.0, samples[1].Value)
+	assert.Equal(t, "job_ok", samples[2].Name)
+	assert.Equal(t, 1.0, samples[2].Value)
+	assert.Equal(t, "queue_depth", samples[3].Name)
+	assert.True(t, samples[3].Timestamp.IsZero())
+
+	_, err = Services.ParseInfluxLineProtocol([]byte("cpu usage=abc"), "")
+	assert.ErrorContains(t, err, "line 1")
+	_, err = Services.ParseInfluxLineProtocol([]byte("cpu"), "")
+	assert.Error(t, err)
+	_, err = Services.ParseInfluxLineProtocol([]byte("cpu value=1"), "h")
+	assert.Error(t, err)
+}
+
+// encodeRemoteWrite 构造 remote-write 请求体，使用只包含字面量的snappy块
+func encodeRemoteWrite(series map[string][]float64, labels map[string]string, at time.Time) []byte {
+	var request []byte
+	for name, values := range series {
+		var ts []byte
+		appendLabel := func(key, value string) {
+			var label []byte
+			label = protowire.AppendTag(label, 1, protowire.BytesType)
+			label = protowire.AppendString(label, key)
+			label = protowire.AppendTag(label, 2, protowire.BytesType)
+			label = protowire.AppendString(label, value)
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, label)
+		}
+		appendLabel("__name__", name)
+		for key, value := range labels {
+			appendLabel(key, value)
+		}
+		for i, value := range values {
+			var sample []byte
+			sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+			sample = protowire.AppendFixed64(sample, math.Float64bits(value))
+			sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+			sample = protowire.AppendVarint(sample, uint64(at.Add(time.Duration(i)*time.Second).UnixMilli()))
+			ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, sample)
+		}
+		request = protowire.AppendTag(request, 1, protowire.BytesType)
+		request = protowire.AppendBytes(request, ts)
+	}
+
+	compressed := binary.AppendUvarint(nil, uint64(len(request)))
+	compressed = append(compressed, 61<<2, byte(len(request)-1), byte((len(request)-1)>>8))
+	return append(compressed, request...)
+}
+
+func TestParsePrometheusRemoteWrite(t *testing.T) {
+	at := time.Now().Truncate(time.Millisecond)
+	body := encodeRemoteWrite(map[string][]float64{"http_requests_total": {10, 12}}, map[string]string{"job": "api"}, at)
+
+	samples, err := Services.ParsePrometheusRemoteWrite(body, 1<<20)
+	require.NoError(t, err)
+	require.Len(t, samples, 2)
+	assert.Equal(t, "http_requests_total", samples[0].Name)
+	assert.Equal(t, map[string]string{"job": "api"}, samples[0].Labels)
+	assert.Equal(t, 12.0, samples[1].Value)
+	assert.True(t, samples[1].Timestamp.Equal(at.Add(time.Second)))
+
+	_, err = Services.ParsePrometheusRemoteWrite(body, 10)
+	assert.ErrorContains(t, err, "exceeds limit")
+	_, err = Services.ParsePrometheusRemoteWrite(body[:len(body)-3], 1<<20)
+	assert.Error(t, err)
+}
+
+func newIngestTestService(t *testing.T, configure func(config *Config.MonitoringConfig)) (*Services.MetricIngestService, *Services.MonitoringCore) {
+	config := &Config.MonitoringConfig{}
+	config.SetDefaults()
+	config.Ingest.Enabled = true
+	config.Ingest.Tokens = "batch-jobs:secret-1, billing:secret-2"
+	if configure != nil {
+		configure(config)
+	}
+	require.NoError(t, config.Validate())
+
+	core := newTestMonitoringCore()
+	service := Services.NewMetricIngestService(config)
+	service.SetMonitoringCore(core)
+	return service, core
+}
+
+func TestMetricIngestValidationAndStorage(t *testing.T) {
+	service, core := newIngestTestService(t, nil)
+	history := setupMetricHistory(t)
+	service.SetMetricHistory(history)
+
+	source, err := service.Authenticate("secret-2")
+	require.NoError(t, err)
+	assert.Equal(t, "billing", source)
+	_, err = service.Authenticate("secret-3")
+	assert.ErrorIs(t, err, Services.ErrIngestUnauthorized)
+
+	now := time.Now()
+	result, err := service.Ingest(source, []Services.IngestSample{
+		{Name: "invoices_generated", Value: 5, Timestamp: now.Add(-time.Minute)},
+		{Name: "invoices_generated", Value: 8, Timestamp: now},
+		{Name: "job_duration_seconds", Value: 30, Labels: map[string]string{"job": "billing", "env": "prod"}},
+		{Name: "bad-name", Value: 1},
+		{Name: "nan_value", Value: math.NaN()},
+		{Name: "stale", Value: 1, Timestamp: now.Add(-2 * time.Hour)},
+		{Name: "reserved_label", Value: 1, Labels: map[string]string{"__name__": "x"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Accepted)
+	assert.Equal(t, 4, result.Rejected)
+	assert.Len(t, result.Errors, 4)
+
+	values := core.Values()
+	assert.Equal(t, 8.0, values["invoices_generated"])
+	assert.Equal(t, 30.0, values[`job_duration_seconds{env="prod",job="billing"}`])
+
+	stored, err := history.Query("invoices_generated", now.Add(-time.Hour), now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Len(t, stored, 2)
+}
+
+func TestMetricIngestQuota(t *testing.T) {
+	service, _ := newIngestTestService(t, func(config *Config.MonitoringConfig) {
+		config.Ingest.QuotaPerMinute = 3
+		config.Ingest.MaxBatchSize = 5
+	})
+
+	samples := []Services.IngestSample{{Name: "a", Value: 1}, {Name: "b", Value: 2}}
+	_, err := service.Ingest("batch-jobs", samples)
+	require.NoError(t, err)
+
+	// 整批超过剩余额度时拒绝整批，其他来源不受影响
+	_, err = service.Ingest("batch-jobs", samples)
+	var quotaErr *Services.MetricIngestQuotaError
+	require.ErrorAs(t, err, &quotaErr)
+	assert.Equal(t, 3, quotaErr.Limit)
+	assert.Greater(t, quotaErr.RetryAfter, time.Duration(0))
+	_, err = service.Ingest("billing", samples)
+	require.NoError(t, err)
+
+	_, err = service.Ingest("billing", make([]Services.IngestSample, 6))
+	assert.ErrorIs(t, err, Services.ErrIngestBatchTooLarge)
+}
+
+func TestIngestMetricsEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service, core := newIngestTestService(t, func(config *Config.MonitoringConfig) {
+		config.Ingest.MaxBodySize = 4096
+		config.Ingest.QuotaPerMinute = 5
+	})
+	controller := Controllers.NewMonitoringController()
+	router := gin.New()
+	router.POST("/ingest", controller.IngestMetrics)
+	post := func(query, contentType, token string, body []byte) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/ingest"+query, bytes.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusServiceUnavailable, post("", "application/json", "Bearer secret-1", nil).Code)
+	controller.SetMetricIngestService(service)
+
+	jsonBody := []byte(`{"metrics":[{"name":"export_rows","value":120,"labels":{"table":"orders"}},{"name":"export_failed"}]}`)
+	assert.Equal(t, http.StatusUnauthorized, post("", "application/json", "", jsonBody).Code)
+	assert.Equal(t, http.StatusUnauthorized, post("", "application/json", "Bearer wrong", jsonBody).Code)
+	assert.Equal(t, http.StatusBadRequest, post("", "application/json", "Bearer secret-1", []byte("{")).Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post("", "application/json", "Bearer secret-1", bytes.Repeat([]byte(" "), 5000)).Code)
+
+	w := post("", "application/json", "Bearer secret-1", jsonBody)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Data Services.MetricIngestResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, Services.MetricIngestResult{
+		Source: "batch-jobs", Accepted: 1, Rejected: 1, Errors: resp.Data.Errors,
+	}, resp.Data)
+	assert.Equal(t, 120.0, core.Values()[`export_rows{table="orders"}`])
+
+	// Influx 客户端使用 Token 前缀，按 Content-Type 识别为行协议
+	w = post("?precision=s", "text/plain; charset=utf-8", "Token secret-1", []byte(fmt.Sprintf("backup size=2048i %d\n", time.Now().Unix())))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 2048.0, core.Values()["backup_size"])
+
+	remoteWrite := encodeRemoteWrite(map[string][]float64{"sync_lag_seconds": {1.5}}, nil, time.Now())
+	w = post("", "application/x-protobuf", "Bearer secret-1", remoteWrite)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 1.5, core.Values()["sync_lag_seconds"])
+
+	// 本分钟已接收3个采样，剩余额度不足
+	w = post("?format=influx", "application/octet-stream", "Bearer secret-1", []byte(strings.Repeat("m value=1\n", 3)))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusBadRequest, post("?format=csv", "text/csv", "Bearer secret-2", []byte("a,1")).Code)
+}
@@ -0,0 +1,202 @@
+package Monitoring
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// queuedChannel 将收到的告警写入缓冲通道，用于等待异步发送
+type queuedChannel struct {
+	name    string
+	enabled bool
+	alerts  chan Services.MonitoringAlert
+}
+
+func newQueuedChannel(name string) *queuedChannel {
+	return &queuedChannel{name: name, enabled: true, alerts: make(chan Services.MonitoringAlert, 10)}
+}
+
+func (c *queuedChannel) SendAlert(alert Services.MonitoringAlert) error {
+	c.alerts <- alert
+	return nil
+}
+func (c *queuedChannel) GetName() string { return c.name }
+func (c *queuedChannel) IsEnabled() bool { return c.enabled }
+
+func (c *queuedChannel) receive(t *testing.T) Services.MonitoringAlert {
+	select {
+	case alert := <-c.alerts:
+		return alert
+	case <-time.After(time.Second):
+		t.Fatalf("通道 %s 未收到告警", c.name)
+		return Services.MonitoringAlert{}
+	}
+}
+
+func newTestMonitoringCore() *Services.MonitoringCore {
+	core := Services.NewMonitoringCore()
+	core.AlertEngine().SetResolutionPolicy(0, time.Minute, 0)
+	return core
+}
+
+func TestMonitoringCoreCollectorRegistry(t *testing.T) {
+	core := newTestMonitoringCore()
+	assert.Equal(t, []string{"runtime"}, core.Collectors())
+
+	depth := 5.0
+	require.NoError(t, core.RegisterCollector(Services.NewMetricCollector("queue", func(ctx context.Context) (map[string]float64, error) {
+		return map[string]float64{"queue_depth": depth}, nil
+	})))
+	require.NoError(t, core.RegisterCollector(Services.NewMetricCollector("broken", func(ctx context.Context) (map[string]float64, error) {
+		return nil, errors.New("connection refused")
+	})))
+	assert.Equal(t, []string{"runtime", "queue", "broken"}, core.Collectors())
+
+	// 单个采集器失败不影响其他采集器
+	values := core.Collect()
+	assert.Equal(t, 5.0, values["queue_depth"])
+	assert.Contains(t, values, "goroutines")
+
+	// 同名采集器替换但保持顺序
+	require.NoError(t, core.RegisterCollector(Services.NewMetricCollector("queue", func(ctx context.Context) (map[string]float64, error) {
+		return map[string]float64{"queue_depth": 7}, nil
+	})))
+	assert.Equal(t, []string{"runtime", "queue", "broken"}, core.Collectors())
+	assert.Equal(t, 7.0, core.Collect()["queue_depth"])
+
+	core.UnregisterCollector("broken")
+	assert.Equal(t, []string{"runtime", "queue"}, core.Collectors())
+
+	core.Observe("pushed_metric", 3)
+	value, err := core.MetricValue("pushed_metric")
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, value)
+	_, err = core.MetricValue("missing")
+	assert.Error(t, err)
+}
+
+func TestMonitoringCoreEvaluatesRulesThroughPipeline(t *testing.T) {
+	core := newTestMonitoringCore()
+	depth := 20.0
+	require.NoError(t, core.RegisterCollector(Services.NewMetricCollector("queue", func(ctx context.Context) (map[string]float64, error) {
+		return map[string]float64{"queue_depth": depth}, nil
+	})))
+
+	all := newQueuedChannel("webhook")
+	targeted := newQueuedChannel("telegram")
+	disabled := newQueuedChannel("wechat_work")
+	disabled.enabled = false
+	for _, channel := range []*queuedChannel{all, targeted, disabled} {
+		core.Pipeline().AddChannel(channel)
+	}
+
+	require.NoError(t, core.AddRule(&Services.AlertRule{
+		ID: "queue_backlog", Name: "队列积压", Metric: "queue_depth", Condition: ">", Threshold: 10,
+		Level: Services.AlertLevelError, Enabled: true,
+	}))
+	require.NoError(t, core.AddRule(&Services.AlertRule{
+		ID: "queue_critical", Name: "队列严重积压", Metric: "queue_depth", Condition: ">", Threshold: 15,
+		Level: Services.AlertLevelCritical, Channels: []Services.AlertChannel{"telegram"}, Enabled: true,
+	}))
+
+	require.NoError(t, core.Evaluate())
+	assert.Len(t, core.Alerts("active", 0), 2)
+
+	// 未指定渠道的规则发送到全部启用的通道，指定渠道的规则只发送到对应通道
+	assert.Equal(t, "系统告警: 队列积压", all.receive(t).Title)
+	titles := []string{targeted.receive(t).Title, targeted.receive(t).Title}
+	assert.ElementsMatch(t, []string{"系统告警: 队列积压", "系统告警: 队列严重积压"}, titles)
+	assert.Empty(t, disabled.alerts)
+
+	depth = 1
+	require.NoError(t, core.Evaluate())
+	assert.Len(t, core.Alerts("resolved", 0), 2)
+	resolved := all.receive(t)
+	assert.True(t, resolved.Resolved)
+	assert.Equal(t, "告警已恢复: 队列积压", resolved.Title)
+
+	history := core.AlertHistory(1)
+	require.Len(t, history, 1)
+	stats := core.Stats()
+	assert.Equal(t, []string{"runtime", "queue"}, stats["collectors"])
+	assert.Equal(t, []string{"webhook", "telegram", "wechat_work"}, stats["notification_channels"])
+}
+
+func TestOptimizedMonitoringServiceDelegatesToCore(t *testing.T) {
+	core := newTestMonitoringCore()
+	service := Services.NewOptimizedMonitoringService()
+	service.SetMonitoringCore(core)
+	assert.Equal(t, []string{"runtime", "application"}, core.Collectors())
+
+	ruleIDs := make([]string, 0)
+	for _, rule := range core.Rules() {
+		ruleIDs = append(ruleIDs, rule.ID)
+	}
+	assert.Equal(t, []string{"threshold_cpu_usage", "threshold_goroutines", "threshold_memory_usage"}, ruleIDs)
+
+	// 阈值规则由核心告警引擎评估
+	config := *service.GetConfig()
+	config.MaxMemoryUsage = -1
+	config.MaxCPUUsage = 1e9
+	config.EnableAppMetrics = false
+	service.UpdateConfig(&config)
+	assert.Equal(t, []string{"runtime"}, core.Collectors())
+
+	require.NoError(t, service.Start())
+	defer service.Stop()
+
+	systemMetrics, err := service.GetSystemMetrics()
+	require.NoError(t, err)
+	assert.Greater(t, systemMetrics.Goroutines, 0)
+
+	active, err := service.GetActiveAlerts()
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, "threshold_memory_usage", active[0].(*Services.Alert).RuleID)
+
+	filtered, err := service.GetAlerts("active", string(Services.AlertLevelCritical), 10)
+	require.NoError(t, err)
+	assert.Empty(t, filtered)
+}
+
+func TestMonitoringIntegrationServiceDelegatesToCore(t *testing.T) {
+	storageManager := Storage.NewStorageManager(&Config.StorageConfig{BasePath: t.TempDir()})
+	core := newTestMonitoringCore()
+	service := Services.NewMonitoringIntegrationService(storageManager)
+	service.SetMonitoringCore(core)
+
+	channel := newQueuedChannel("webhook")
+	service.AddNotificationChannel(channel)
+	require.Len(t, core.Pipeline().Channels(), 1)
+
+	service.UpdatePerformanceMetrics(map[string]interface{}{"response_time": 1500.0})
+	active := core.Alerts("active", 0)
+	require.Len(t, active, 1)
+	assert.Equal(t, "threshold_response_time", active[0].RuleID)
+	assert.Equal(t, "alert_rule", channel.receive(t).Type)
+
+	// 重复上报只累加次数，不重复通知
+	service.UpdatePerformanceMetrics(map[string]interface{}{"response_time": 1600.0})
+	assert.Equal(t, 2, core.Alerts("active", 0)[0].Count)
+
+	// 调整阈值后条件恢复
+	service.SetAlertThreshold("response_time", 2000)
+	service.UpdatePerformanceMetrics(map[string]interface{}{"response_time": 1600.0})
+	assert.Empty(t, core.Alerts("active", 0))
+
+	history, err := service.GetAlertHistory(10)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.True(t, history[0].Resolved)
+
+	service.RemoveNotificationChannel("webhook")
+	assert.Empty(t, core.Pipeline().Channels())
+}
@@ -0,0 +1,234 @@
+package Monitoring
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingServer 记录请求路径和JSON请求体，按 respond 返回响应
+func recordingServer(t *testing.T, respond func(w http.ResponseWriter, hit int)) (*httptest.Server, func() []map[string]interface{}, func() []string) {
+	var mu sync.Mutex
+	var bodies []map[string]interface{}
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		bodies = append(bodies, body)
+		paths = append(paths, r.URL.Path)
+		hit := len(bodies)
+		mu.Unlock()
+		respond(w, hit)
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []map[string]interface{} {
+			mu.Lock()
+			defer mu.Unlock()
+			return append([]map[string]interface{}(nil), bodies...)
+		}, func() []string {
+			mu.Lock()
+			defer mu.Unlock()
+			return append([]string(nil), paths...)
+		}
+}
+
+func testAlert() Services.MonitoringAlert {
+	return Services.MonitoringAlert{
+		Severity:  "critical",
+		Title:     "CPU usage 95.5%",
+		Message:   "host web-1 (prod) is over threshold!",
+		Source:    "system",
+		Timestamp: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+func TestEscapeTelegramMarkdownV2(t *testing.T) {
+	assert.Equal(t, `a\_b\*c\[d\]\(e\)\~\`+"`"+`\>\#\+\-\=\|\{\}\.\!\\`,
+		Services.EscapeTelegramMarkdownV2("a_b*c[d](e)~`>#+-=|{}.!\\"))
+	assert.Equal(t, "中文告警", Services.EscapeTelegramMarkdownV2("中文告警"))
+}
+
+func TestTelegramChannelSendsMarkdownToEachChat(t *testing.T) {
+	server, bodies, paths := recordingServer(t, func(w http.ResponseWriter, hit int) {
+		w.Write([]byte(`{"ok":true,"result":{}}`))
+	})
+	config := outboundConfig()
+	config.NotificationConfig.Telegram.Enabled = true
+	config.NotificationConfig.Telegram.APIURL = server.URL
+	config.NotificationConfig.Telegram.BotToken = "123:token"
+	config.NotificationConfig.Telegram.ChatIDs = "-100, 42"
+	config.NotificationConfig.Telegram.MentionUsers = "oncall_ops,@admin"
+
+	channel := Services.NewTelegramNotificationChannel(config, Services.NewOutboundHTTPClient(config))
+	require.True(t, channel.IsEnabled())
+	require.NoError(t, channel.SendAlert(testAlert()))
+
+	assert.Equal(t, []string{"/bot123:token/sendMessage", "/bot123:token/sendMessage"}, paths())
+	sent := bodies()
+	require.Len(t, sent, 2)
+	assert.Equal(t, "-100", sent[0]["chat_id"])
+	assert.Equal(t, "42", sent[1]["chat_id"])
+	assert.Equal(t, "MarkdownV2", sent[0]["parse_mode"])
+	text := sent[0]["text"].(string)
+	assert.True(t, strings.HasPrefix(text, `*\[CRITICAL\] CPU usage 95\.5%*`), text)
+	assert.Contains(t, text, `host web\-1 \(prod\) is over threshold\!`)
+	assert.Contains(t, text, `@oncall\_ops @admin`)
+}
+
+func TestTelegramChannelRateLimits(t *testing.T) {
+	server, bodies, _ := recordingServer(t, func(w http.ResponseWriter, hit int) {
+		if hit == 2 {
+			w.Write([]byte(`{"ok":false,"error_code":429,"description":"Too Many Requests","parameters":{"retry_after":30}}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	})
+	config := outboundConfig()
+	config.NotificationConfig.Telegram.Enabled = true
+	config.NotificationConfig.Telegram.APIURL = server.URL
+	config.NotificationConfig.Telegram.BotToken = "t"
+	config.NotificationConfig.Telegram.ChatIDs = "1"
+	config.NotificationConfig.Telegram.RateLimit = 5
+
+	channel := Services.NewTelegramNotificationChannel(config, Services.NewOutboundHTTPClient(config))
+	require.NoError(t, channel.SendAlert(testAlert()))
+
+	// 服务商返回429后按 retry_after 暂停该会话，不再请求
+	err := channel.SendAlert(testAlert())
+	var rateLimitErr *Services.NotificationRateLimitError
+	require.True(t, errors.As(err, &rateLimitErr), "%v", err)
+	assert.Equal(t, 30*time.Second, rateLimitErr.RetryAfter)
+
+	err = channel.SendAlert(testAlert())
+	require.True(t, errors.As(err, &rateLimitErr))
+	assert.Len(t, bodies(), 2)
+}
+
+func TestWeChatWorkMarkdownPayload(t *testing.T) {
+	server, bodies, _ := recordingServer(t, func(w http.ResponseWriter, hit int) {
+		w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	})
+	config := outboundConfig()
+	config.NotificationConfig.WeChatWork.Enabled = true
+	config.NotificationConfig.WeChatWork.WebhookURL = server.URL + "/cgi-bin/webhook/send?key=k"
+	config.NotificationConfig.WeChatWork.MentionedList = "zhangsan,@all"
+
+	channel := Services.NewWeChatWorkNotificationChannel(config, Services.NewOutboundHTTPClient(config))
+	require.NoError(t, channel.SendAlert(testAlert()))
+
+	sent := bodies()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "markdown", sent[0]["msgtype"])
+	content := sent[0]["markdown"].(map[string]interface{})["content"].(string)
+	assert.True(t, strings.HasPrefix(content, `### <font color="warning">[CRITICAL]</font> CPU usage 95.5%`), content)
+	assert.Contains(t, content, "> 来源: system")
+	assert.Contains(t, content, "<@zhangsan>")
+	assert.NotContains(t, content, "<@@all>")
+
+	// 超长正文截断后仍保留提及，且不超过4096字节
+	long := testAlert()
+	long.Message = strings.Repeat("告警", 3000)
+	payload := channel.BuildWeChatWorkPayload(long)
+	content = payload["markdown"].(map[string]interface{})["content"].(string)
+	assert.LessOrEqual(t, len(content), 4096)
+	assert.True(t, strings.HasSuffix(content, "<@zhangsan>"))
+}
+
+func TestWeChatWorkTextMentionsAndRateLimit(t *testing.T) {
+	server, bodies, _ := recordingServer(t, func(w http.ResponseWriter, hit int) {
+		w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	})
+	config := outboundConfig()
+	config.NotificationConfig.WeChatWork.Enabled = true
+	config.NotificationConfig.WeChatWork.WebhookURL = server.URL
+	config.NotificationConfig.WeChatWork.MsgType = "text"
+	config.NotificationConfig.WeChatWork.MentionedList = "@all"
+	config.NotificationConfig.WeChatWork.MentionedMobiles = "13800138000"
+	config.NotificationConfig.WeChatWork.RateLimit = 2
+
+	channel := Services.NewWeChatWorkNotificationChannel(config, Services.NewOutboundHTTPClient(config))
+	require.NoError(t, channel.SendAlert(testAlert()))
+	require.NoError(t, channel.SendAlert(testAlert()))
+
+	err := channel.SendAlert(testAlert())
+	var rateLimitErr *Services.NotificationRateLimitError
+	require.True(t, errors.As(err, &rateLimitErr), "%v", err)
+	assert.Greater(t, rateLimitErr.RetryAfter, 50*time.Second)
+
+	sent := bodies()
+	require.Len(t, sent, 2)
+	text := sent[0]["text"].(map[string]interface{})
+	assert.Equal(t, "text", sent[0]["msgtype"])
+	assert.Equal(t, []interface{}{"@all"}, text["mentioned_list"])
+	assert.Equal(t, []interface{}{"13800138000"}, text["mentioned_mobile_list"])
+}
+
+func TestWeChatWorkProviderFrequencyLimit(t *testing.T) {
+	server, bodies, _ := recordingServer(t, func(w http.ResponseWriter, hit int) {
+		w.Write([]byte(`{"errcode":45009,"errmsg":"api freq out of limit"}`))
+	})
+	config := outboundConfig()
+	config.NotificationConfig.WeChatWork.Enabled = true
+	config.NotificationConfig.WeChatWork.WebhookURL = server.URL
+
+	channel := Services.NewWeChatWorkNotificationChannel(config, Services.NewOutboundHTTPClient(config))
+	var rateLimitErr *Services.NotificationRateLimitError
+	require.True(t, errors.As(channel.SendAlert(testAlert()), &rateLimitErr))
+	require.True(t, errors.As(channel.SendAlert(testAlert()), &rateLimitErr))
+	assert.Len(t, bodies(), 1)
+}
+
+func TestNotificationChannelTestEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server, bodies, _ := recordingServer(t, func(w http.ResponseWriter, hit int) {
+		w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	})
+	config := outboundConfig()
+	config.NotificationConfig.WeChatWork.Enabled = true
+	config.NotificationConfig.WeChatWork.WebhookURL = server.URL
+	config.NotificationConfig.WeChatWork.RateLimit = 1
+	client := Services.NewOutboundHTTPClient(config)
+
+	controller := Controllers.NewMonitoringController()
+	controller.SetNotificationChannels([]Services.NotificationChannel{
+		Services.NewWeChatWorkNotificationChannel(config, client),
+		Services.NewTelegramNotificationChannel(config, client),
+	})
+	router := gin.New()
+	router.GET("/channels", controller.GetNotificationChannels)
+	router.POST("/channels/:channel/test", controller.TestNotificationChannel)
+
+	post := func(channel string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/channels/"+channel+"/test", nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, post("wechat_work").Code)
+	require.Len(t, bodies(), 1)
+	assert.Contains(t, bodies()[0]["markdown"].(map[string]interface{})["content"], "告警通知测试")
+
+	w := post("wechat_work")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusBadRequest, post("telegram").Code)
+	assert.Equal(t, http.StatusNotFound, post("pagerduty").Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/channels", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `{"enabled":false,"name":"telegram"}`)
+}
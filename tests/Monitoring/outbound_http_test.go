@@ -0,0 +1,257 @@
+package Monitoring
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Services"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func outboundConfig() *Config.MonitoringConfig {
+	config := &Config.MonitoringConfig{}
+	config.SetDefaults()
+	config.OutboundHTTP.Timeout = time.Second
+	config.OutboundHTTP.RetryBackoff = time.Millisecond
+	config.OutboundHTTP.MinRequests = 4
+	config.OutboundHTTP.BreakerOpenTimeout = 50 * time.Millisecond
+	return config
+}
+
+// flakyServer 前 failures 次请求返回503，之后返回200
+func flakyServer(t *testing.T, failures int32) (*httptest.Server, *int32) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) <= atomic.LoadInt32(&failures) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+func dependencyStats(t *testing.T, client *Services.OutboundHTTPClient, name string) Services.OutboundDependencyStats {
+	for _, stats := range client.Stats() {
+		if stats.Name == name {
+			return stats
+		}
+	}
+	t.Fatalf("dependency %s not found", name)
+	return Services.OutboundDependencyStats{}
+}
+
+func TestOutboundRetries(t *testing.T) {
+	server, hits := flakyServer(t, 2)
+	client := Services.NewOutboundHTTPClient(outboundConfig())
+
+	resp, err := client.Get(context.Background(), "feed", server.URL, nil)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "ok", string(body))
+	assert.Equal(t, int32(3), atomic.LoadInt32(hits))
+
+	stats := dependencyStats(t, client, "feed")
+	assert.Equal(t, Services.BreakerStateClosed, stats.State)
+	assert.Equal(t, 3, stats.Requests)
+	assert.Equal(t, 2, stats.Failures)
+	assert.Equal(t, 2, stats.Retries)
+	assert.Contains(t, stats.LastError, "503")
+}
+
+func TestOutboundRetryBudget(t *testing.T) {
+	config := outboundConfig()
+	config.OutboundHTTP.MaxRetries = 1
+	config.OutboundHTTP.RetryBudgetRatio = 0
+	config.OutboundHTTP.BreakerFailureRate = 0
+	server, hits := flakyServer(t, 100)
+	client := Services.NewOutboundHTTPClient(config)
+
+	// 预算为0时窗口内仍允许 MaxRetries 次重试
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(context.Background(), "feed", server.URL, nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(hits))
+	assert.Equal(t, int64(1), dependencyStats(t, client, "feed").BudgetDenied)
+}
+
+func TestOutboundCircuitBreaker(t *testing.T) {
+	config := outboundConfig()
+	config.OutboundHTTP.MaxRetries = 0
+	config.OutboundHTTP.ErrorRateSLO = 5
+	var failures int32 = 100
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) <= atomic.LoadInt32(&failures) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	client := Services.NewOutboundHTTPClient(config)
+	var mu sync.Mutex
+	var alertTypes []string
+	client.OnAlert(func(alert Services.MonitoringAlert) {
+		mu.Lock()
+		defer mu.Unlock()
+		alertTypes = append(alertTypes, alert.Type)
+	})
+
+	for i := 0; i < 4; i++ {
+		resp, err := client.Get(context.Background(), "feed", server.URL, nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.Equal(t, Services.BreakerStateOpen, dependencyStats(t, client, "feed").State)
+	assert.ElementsMatch(t, []string{Services.OutboundAlertCircuitOpen, Services.OutboundAlertErrorRateSLO}, alertTypes)
+
+	// 熔断期间请求不发出
+	_, err := client.Get(context.Background(), "feed", server.URL, nil)
+	assert.ErrorIs(t, err, Services.ErrCircuitOpen)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&hits))
+
+	// 半开探测失败时重新熔断
+	time.Sleep(60 * time.Millisecond)
+	resp, err := client.Get(context.Background(), "feed", server.URL, nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, Services.BreakerStateOpen, dependencyStats(t, client, "feed").State)
+
+	// 依赖恢复后半开探测成功，熔断关闭
+	atomic.StoreInt32(&failures, 0)
+	time.Sleep(60 * time.Millisecond)
+	resp, err = client.Get(context.Background(), "feed", server.URL, nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	stats := dependencyStats(t, client, "feed")
+	assert.Equal(t, Services.BreakerStateClosed, stats.State)
+	assert.Equal(t, int64(1), stats.TotalRejected)
+	assert.Equal(t, int64(6), stats.TotalRequests)
+}
+
+func TestOutboundTimeoutAndLatencySLO(t *testing.T) {
+	config := outboundConfig()
+	config.OutboundHTTP.MaxRetries = 0
+	config.OutboundHTTP.MinRequests = 2
+	config.OutboundHTTP.BreakerFailureRate = 0
+	config.OutboundHTTP.ErrorRateSLO = 0
+	config.OutboundHTTP.LatencySLO = time.Millisecond
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delay, _ := time.ParseDuration(r.URL.Query().Get("delay"))
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	client := Services.NewOutboundHTTPClient(config)
+	client.SetPolicy("slow", Services.OutboundPolicy{Timeout: 50 * time.Millisecond})
+	var alerts []Services.MonitoringAlert
+	client.OnAlert(func(alert Services.MonitoringAlert) { alerts = append(alerts, alert) })
+
+	_, err := client.Get(context.Background(), "slow", server.URL+"?delay=1s", nil)
+	assert.Error(t, err)
+	resp, err := client.Get(context.Background(), "slow", server.URL+"?delay=10ms", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	stats := dependencyStats(t, client, "slow")
+	assert.Equal(t, 1, stats.Failures)
+	assert.Greater(t, stats.P95Latency, 10.0)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, Services.OutboundAlertLatencySLO, alerts[0].Type)
+	assert.Equal(t, "slow", alerts[0].Metadata["dependency"])
+}
+
+func TestWebhookNotificationChannel(t *testing.T) {
+	var hits int32
+	var bodies []Services.MonitoringAlert
+	var token string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert Services.MonitoringAlert
+		json.NewDecoder(r.Body).Decode(&alert)
+		bodies = append(bodies, alert)
+		token = r.Header.Get("X-Token")
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	config := outboundConfig()
+	config.NotificationConfig.Webhook.Enabled = true
+	config.NotificationConfig.Webhook.URL = server.URL
+	config.NotificationConfig.Webhook.Headers = "Content-Type:application/json, X-Token: secret"
+	config.NotificationConfig.Webhook.RetryCount = 1
+	client := Services.NewOutboundHTTPClient(config)
+	channel := Services.NewWebhookNotificationChannel(config, client)
+
+	// 首次返回502后重试，请求体可重放
+	require.NoError(t, channel.SendAlert(Services.MonitoringAlert{ID: "a1", Title: "测试告警"}))
+	require.Len(t, bodies, 2)
+	assert.Equal(t, "a1", bodies[1].ID)
+	assert.Equal(t, bodies[0], bodies[1])
+	assert.Equal(t, "secret", token)
+	assert.Equal(t, 2, dependencyStats(t, client, "webhook").Requests)
+
+	channel.SetEnabled(false)
+	assert.Error(t, channel.SendAlert(Services.MonitoringAlert{ID: "a2"}))
+}
+
+// TestWebhookNotificationChannelDefaultRetries 未配置重试次数时使用出站客户端的默认重试次数
+func TestWebhookNotificationChannelDefaultRetries(t *testing.T) {
+	server, hits := flakyServer(t, 2)
+
+	config := outboundConfig()
+	config.NotificationConfig.Webhook.Enabled = true
+	config.NotificationConfig.Webhook.URL = server.URL
+	config.NotificationConfig.Webhook.RetryCount = 0
+	client := Services.NewOutboundHTTPClient(config)
+	channel := Services.NewWebhookNotificationChannel(config, client)
+
+	require.Equal(t, 2, client.DefaultPolicy().MaxRetries)
+	require.NoError(t, channel.SendAlert(Services.MonitoringAlert{ID: "a1", Title: "测试告警"}))
+	assert.Equal(t, int32(3), atomic.LoadInt32(hits))
+}
+
+func TestOutboundWithHTTPClientSharesState(t *testing.T) {
+	server, hits := flakyServer(t, 1)
+	client := Services.NewOutboundHTTPClient(outboundConfig())
+
+	// 自定义客户端的请求经过同一份策略、统计和熔断状态
+	var dialed int32
+	custom := client.WithHTTPClient(&http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			atomic.AddInt32(&dialed, 1)
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}})
+	client.SetPolicy("custom", Services.OutboundPolicy{Timeout: time.Second})
+
+	resp, err := custom.Get(context.Background(), "custom", server.URL, nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(hits), "策略不重试时只发送一次")
+	assert.Positive(t, atomic.LoadInt32(&dialed))
+
+	stats := dependencyStats(t, client, "custom")
+	assert.Equal(t, 1, stats.Requests)
+	assert.Equal(t, 1, stats.Failures)
+}
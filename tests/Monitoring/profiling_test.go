@@ -0,0 +1,128 @@
+package Monitoring
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Services"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func profilingConfig(t *testing.T) *Config.MonitoringConfig {
+	config := &Config.MonitoringConfig{}
+	config.SetDefaults()
+	config.Profiling.Path = t.TempDir()
+	// 默认关闭所有阈值，由各用例按需开启
+	config.ApplicationMonitoring.GoroutineThreshold = 0
+	config.ApplicationMonitoring.HeapThreshold = 0
+	config.ApplicationMonitoring.MemoryLeakThreshold = 0
+	return config
+}
+
+func TestProfilingAutoCapture(t *testing.T) {
+	config := profilingConfig(t)
+	config.ApplicationMonitoring.GoroutineThreshold = 1
+	config.ApplicationMonitoring.HeapThreshold = 1
+	service := Services.NewProfilingService(config)
+
+	// 保证堆内存超过1MB
+	ballast := make([]byte, 2*1024*1024)
+	captured := service.CheckThresholds()
+	_ = ballast[len(ballast)-1]
+
+	require.Len(t, captured, 2)
+	assert.Equal(t, "goroutine", captured[0].Type)
+	assert.Equal(t, Services.ProfileReasonGoroutineThreshold, captured[0].Reason)
+	assert.Equal(t, "heap", captured[1].Type)
+	assert.Equal(t, Services.ProfileReasonHeapThreshold, captured[1].Reason)
+	for _, info := range captured {
+		assert.Greater(t, info.Size, int64(0))
+		assert.FileExists(t, filepath.Join(config.Profiling.Path, info.Name))
+	}
+
+	// 冷却时间内不重复采集
+	assert.Empty(t, service.CheckThresholds())
+}
+
+func TestProfilingRetentionAndLookup(t *testing.T) {
+	config := profilingConfig(t)
+	config.Profiling.MaxProfiles = 2
+	service := Services.NewProfilingService(config)
+
+	var names []string
+	for _, profileType := range []string{"goroutine", "heap", "allocs"} {
+		info, err := service.Capture(profileType, "Manual Check")
+		require.NoError(t, err)
+		assert.Equal(t, "manual_check", info.Reason)
+		names = append(names, info.Name)
+	}
+
+	profiles, err := service.List()
+	require.NoError(t, err)
+	require.Len(t, profiles, 2)
+	assert.Equal(t, names[2], profiles[0].Name)
+	assert.Equal(t, names[1], profiles[1].Name)
+	assert.NoFileExists(t, filepath.Join(config.Profiling.Path, names[0]))
+
+	// 只接受本服务生成的文件名
+	require.NoError(t, os.WriteFile(filepath.Join(config.Profiling.Path, "notes.txt"), []byte("x"), 0644))
+	for _, name := range []string{"notes.txt", "../" + names[2], names[0]} {
+		_, err := service.Path(name)
+		assert.ErrorIs(t, err, Services.ErrProfileNotFound, name)
+	}
+
+	require.NoError(t, service.Delete(names[2]))
+	profiles, err = service.List()
+	require.NoError(t, err)
+	assert.Len(t, profiles, 1)
+
+	_, err = service.Capture("cpu", "")
+	assert.ErrorIs(t, err, Services.ErrProfileTypeUnsupported)
+	_, err = service.Capture("unknown", "")
+	assert.ErrorIs(t, err, Services.ErrProfileTypeUnsupported)
+}
+
+func TestProfilingCPUCapture(t *testing.T) {
+	config := profilingConfig(t)
+	config.Profiling.MaxCPUDuration = 100 * time.Millisecond
+	service := Services.NewProfilingService(config)
+
+	start := time.Now()
+	info, err := service.CaptureCPU(context.Background(), time.Minute, "")
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 10*time.Second, "采集时长应受 MaxCPUDuration 限制")
+	assert.Equal(t, "cpu", info.Type)
+	assert.Equal(t, Services.ProfileReasonManual, info.Reason)
+}
+
+func TestPprofEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := Services.NewProfilingService(profilingConfig(t))
+	controller := Controllers.NewProfilingController(service, nil)
+	router := gin.New()
+	router.GET("/debug/pprof/*name", controller.Pprof)
+
+	cases := map[string]string{
+		"/debug/pprof/":                  "goroutine",
+		"/debug/pprof/goroutine?debug=1": "goroutine profile",
+		"/debug/pprof/cmdline":           os.Args[0],
+	}
+	for path, expected := range cases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.True(t, strings.Contains(w.Body.String(), expected), path)
+	}
+}
@@ -0,0 +1,120 @@
+package Monitoring
+
+import (
+	"cloud-platform-api/app/Services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricRingBufferKeepsRecentSamples(t *testing.T) {
+	buffer := Services.NewMetricRingBuffer(3)
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		buffer.Record("cpu_usage", float64(i), now.Add(time.Duration(i-4)*time.Minute))
+	}
+
+	latest, ok := buffer.Latest("cpu_usage")
+	require.True(t, ok)
+	assert.Equal(t, 4.0, latest.Value)
+	_, ok = buffer.Latest("missing")
+	assert.False(t, ok)
+
+	// 写满后覆盖最旧的采样，按时间升序返回
+	samples, covered := buffer.Range("cpu_usage", now.Add(-2*time.Minute), now)
+	assert.True(t, covered)
+	require.Len(t, samples, 3)
+	assert.Equal(t, []float64{2, 3, 4}, []float64{samples[0].Value, samples[1].Value, samples[2].Value})
+
+	samples, covered = buffer.Range("cpu_usage", now.Add(-time.Hour), now)
+	assert.False(t, covered, "更早的采样已被覆盖，需要回退到指标历史")
+	assert.Len(t, samples, 3)
+	assert.Equal(t, 1, buffer.Metrics())
+}
+
+func TestSystemHealthReadsRecentSamplesWithDatabaseFallback(t *testing.T) {
+	core := newTestMonitoringCore()
+	service := Services.NewOptimizedMonitoringService()
+	service.SetMonitoringCore(core)
+	service.SetStaleAfter(time.Minute)
+
+	// 没有采样也没有指标历史
+	metrics, err := service.GetCurrentMetrics()
+	require.NoError(t, err)
+	assert.NotContains(t, metrics, "system")
+	freshness := metrics["freshness"].(map[string]Services.MetricFreshness)["system"]
+	assert.Equal(t, Services.MetricSourceNone, freshness.Source)
+	assert.True(t, freshness.Stale)
+
+	// 缓冲区为空时回退到指标历史中的最近采样，超过过期时间标记为过期
+	history := setupMetricHistory(t)
+	service.SetMetricHistoryService(history)
+	seedMetric(t, history, "cpu_usage", time.Now().Add(-20*time.Minute), time.Now().Add(-10*time.Minute), time.Minute)
+	health := service.GetSystemHealth()
+	freshness = health["freshness"].(Services.MetricFreshness)
+	assert.Equal(t, Services.MetricSourceDatabase, freshness.Source)
+	assert.True(t, freshness.Stale)
+	assert.InDelta(t, 600, freshness.AgeSeconds, 5)
+	system := health["metrics"].(map[string]interface{})["system"].(*Services.SystemMetrics)
+	assert.Equal(t, 48.0, system.CPUUsage)
+
+	// 采集后从内存读取
+	core.Collect()
+	health = service.GetSystemHealth()
+	freshness = health["freshness"].(Services.MetricFreshness)
+	assert.Equal(t, Services.MetricSourceMemory, freshness.Source)
+	assert.False(t, freshness.Stale)
+	require.NotNil(t, freshness.UpdatedAt)
+	system = health["metrics"].(map[string]interface{})["system"].(*Services.SystemMetrics)
+	assert.Greater(t, system.Goroutines, 0)
+	assert.Equal(t, *freshness.UpdatedAt, system.Timestamp)
+}
+
+func TestDashboardWidgetsPreferRecentSamples(t *testing.T) {
+	service, core := setupDashboardService(t)
+	history := setupMetricHistory(t)
+	service.SetMetricHistory(history)
+	dashboard, err := service.CreateDashboard(Services.MonitoringDashboardInput{Name: "实时"}, dashboardOwner)
+	require.NoError(t, err)
+
+	gauge, err := service.CreateWidget(dashboard.ID, Services.MonitoringWidgetInput{
+		Name: "队列", Type: "gauge", DataSource: Services.WidgetDataSourceMetric,
+		Query: &Services.WidgetQuery{Metrics: []string{"queue_depth"}},
+	}, dashboardOwner)
+	require.NoError(t, err)
+	line, err := service.CreateWidget(dashboard.ID, Services.MonitoringWidgetInput{
+		Name: "队列趋势", Type: "line", DataSource: Services.WidgetDataSourceMetricHistory,
+		Query: &Services.WidgetQuery{Metrics: []string{"queue_depth"}, Range: "30m", Step: "10m", Aggregation: "max"},
+	}, dashboardOwner)
+	require.NoError(t, err)
+
+	// 内存中没有采样时查询指标历史
+	now := time.Now()
+	seedMetric(t, history, "queue_depth", now.Add(-time.Hour), now.Add(-5*time.Minute), time.Minute)
+	data, err := service.WidgetData(dashboard.ID, gauge.ID, dashboardOwner)
+	require.NoError(t, err)
+	require.NotNil(t, data.Freshness)
+	assert.Equal(t, Services.MetricSourceDatabase, data.Freshness.Source)
+	assert.True(t, data.Freshness.Stale)
+	assert.Contains(t, data.Values, "queue_depth")
+
+	// 缓冲区覆盖查询范围后从内存读取，不再使用历史数据
+	for at := now.Add(-40 * time.Minute); !at.After(now); at = at.Add(time.Minute) {
+		core.Recent().Record("queue_depth", 7, at)
+	}
+	data, err = service.WidgetData(dashboard.ID, line.ID, dashboardOwner)
+	require.NoError(t, err)
+	assert.Equal(t, Services.MetricSourceMemory, data.Freshness.Source)
+	assert.False(t, data.Freshness.Stale)
+	require.Len(t, data.Series, 1)
+	for _, point := range data.Series[0].Points {
+		assert.Equal(t, 7.0, point.Value)
+	}
+
+	data, err = service.WidgetData(dashboard.ID, gauge.ID, dashboardOwner)
+	require.NoError(t, err)
+	assert.Equal(t, Services.MetricSourceMemory, data.Freshness.Source)
+	assert.Equal(t, map[string]float64{"queue_depth": 7}, data.Values)
+}
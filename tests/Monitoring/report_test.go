@@ -0,0 +1,328 @@
+package Monitoring
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	at := func(value string) time.Time {
+		parsed, err := time.ParseInLocation("2006-01-02 15:04", value, time.Local)
+		require.NoError(t, err)
+		return parsed
+	}
+	next := func(expression, after string) time.Time {
+		schedule, err := Services.ParseCronSchedule(expression)
+		require.NoError(t, err, expression)
+		return schedule.Next(at(after))
+	}
+
+	assert.Equal(t, at("2024-03-11 08:00"), next("0 8 * * *", "2024-03-10 08:00"))
+	assert.Equal(t, at("2024-03-10 08:00"), next("0 8 * * *", "2024-03-10 07:59"))
+	// 2024-03-08 是周五，工作时间每15分钟一次
+	assert.Equal(t, at("2024-03-11 09:00"), next("*/15 9-17 * * 1-5", "2024-03-08 17:45"))
+	assert.Equal(t, at("2024-03-08 10:30"), next("*/15 9-17 * * 1-5", "2024-03-08 10:20"))
+	// 日和周同时限定时满足任一即可，7 与 0 都表示周日
+	assert.Equal(t, at("2024-03-10 00:00"), next("0 0 1,15 * 7", "2024-03-09 12:00"))
+	assert.Equal(t, at("2024-04-01 08:00"), next("0 8 1 * *", "2024-03-01 08:00"))
+	assert.True(t, next("0 0 30 2 *", "2024-01-01 00:00").IsZero())
+
+	for _, expression := range []string{"", "0 8 * *", "60 * * * *", "0 8 * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := Services.ParseCronSchedule(expression)
+		assert.Error(t, err, expression)
+	}
+}
+
+// setupReportService 创建使用sqlite和临时目录的报告服务，邮件只写入投递记录
+func setupReportService(t *testing.T) (*Services.MonitoringReportService, *gorm.DB, *Services.MetricHistoryService) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "reports.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&Models.MonitoringSchedule{}, &Models.MonitoringReport{}, &Models.MonitoringReportFile{},
+		&Models.MailMessage{}, &Models.MailSuppression{},
+	))
+
+	config := &Config.MonitoringConfig{}
+	config.SetDefaults()
+	config.Reports.StoragePath = t.TempDir()
+	config.Reports.MaxPoints = 24
+	require.NoError(t, config.Validate())
+
+	emailConfig := &Config.EmailConfig{}
+	emailConfig.SetDefaults()
+	emailConfig.From = "monitor@example.com"
+
+	history := setupMetricHistory(t)
+	service := Services.NewMonitoringReportService(db, config)
+	service.SetMonitoringCore(newTestMonitoringCore())
+	service.SetMetricHistory(history)
+	service.SetMailService(Services.NewMailService(db, emailConfig))
+	service.SetHTTPClient(Services.NewOutboundHTTPClient(config))
+	return service, db, history
+}
+
+func TestMonitoringReportSchedule(t *testing.T) {
+	service, db, history := setupReportService(t)
+
+	var mu sync.Mutex
+	var webhooks []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		webhooks = append(webhooks, payload)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	report, err := service.CreateReport(Services.MonitoringReportInput{
+		Name:       "每日资源报告",
+		Type:       Services.ReportTypeDaily,
+		Format:     Services.ReportFormatHTML,
+		Template:   Services.MonitoringReportTemplate{Metrics: []string{"cpu_usage", "disk_usage"}, IncludeAlerts: true},
+		Recipients: Services.MonitoringReportRecipients{Emails: []string{"ops@example.com"}, Webhooks: []string{server.URL}},
+	}, 1)
+	require.NoError(t, err)
+	require.NotNil(t, report.Schedule)
+	assert.Equal(t, "0 8 * * *", report.Schedule.Expression)
+	assert.Equal(t, "cron", report.Schedule.Type)
+	require.NotNil(t, report.Schedule.NextRun)
+	dueAt := *report.Schedule.NextRun
+	assert.Equal(t, 8, dueAt.Hour())
+
+	// 统计周期为调度时间之前的完整自然日
+	day := time.Date(dueAt.Year(), dueAt.Month(), dueAt.Day(), 0, 0, 0, 0, time.Local)
+	seedMetric(t, history, "cpu_usage", day.Add(-24*time.Hour), day.Add(-time.Minute), 10*time.Minute)
+
+	assert.Equal(t, 0, service.RunDueReports(dueAt.Add(-time.Minute)))
+	assert.Equal(t, 1, service.RunDueReports(dueAt.Add(time.Minute)))
+	assert.Equal(t, 0, service.RunDueReports(dueAt.Add(2*time.Minute)))
+
+	files, total, err := service.ListFiles(report.ID, 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), total)
+	file := files[0]
+	assert.Equal(t, Services.ReportTriggerSchedule, file.Trigger)
+	assert.Equal(t, Services.ReportFileDelivered, file.Status, file.DeliveryError)
+	assert.True(t, file.PeriodStart.Equal(day.AddDate(0, 0, -1)))
+	assert.True(t, file.PeriodEnd.Equal(day))
+	assert.Regexp(t, `^每日资源报告-\d{8}\.html$`, file.FileName)
+
+	stored, contentType, err := service.GetFile(report.ID, file.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "text/html; charset=utf-8", contentType)
+	content, err := os.ReadFile(stored.FilePath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "<polyline")
+	assert.Contains(t, string(content), "disk_usage")
+	assert.Contains(t, string(content), "统计周期内没有告警")
+
+	var schedule Models.MonitoringSchedule
+	require.NoError(t, db.First(&schedule, *report.ScheduleID).Error)
+	assert.Equal(t, 1, schedule.RunCount)
+	assert.Equal(t, 1, schedule.SuccessCount)
+	assert.True(t, schedule.NextRun.After(dueAt))
+
+	var mails []Models.MailMessage
+	require.NoError(t, db.Find(&mails).Error)
+	require.Len(t, mails, 1)
+	assert.Equal(t, "ops@example.com", mails[0].Recipients)
+	assert.Contains(t, mails[0].Payload, file.FileName)
+
+	mu.Lock()
+	require.Len(t, webhooks, 1)
+	assert.Equal(t, "monitoring.report.generated", webhooks[0]["event"])
+	assert.Equal(t, fmt.Sprintf("/api/v1/monitoring/reports/%d/files/%d/download", report.ID, file.ID), webhooks[0]["download"])
+	mu.Unlock()
+
+	// 停用后不再调度，删除时清理文件
+	disabled := false
+	_, err = service.UpdateReport(report.ID, Services.MonitoringReportInput{
+		Name: "每日资源报告", Type: Services.ReportTypeDaily, Enabled: &disabled,
+		Template: Services.MonitoringReportTemplate{Metrics: []string{"cpu_usage"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, service.RunDueReports(dueAt.Add(48*time.Hour)))
+
+	require.NoError(t, service.DeleteReport(report.ID))
+	_, err = os.Stat(stored.FilePath)
+	assert.True(t, os.IsNotExist(err))
+	var count int64
+	db.Model(&Models.MonitoringSchedule{}).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestMonitoringReportValidationAndDeliveryFailure(t *testing.T) {
+	service, _, _ := setupReportService(t)
+	template := Services.MonitoringReportTemplate{Metrics: []string{"cpu_usage"}}
+
+	invalid := []Services.MonitoringReportInput{
+		{Name: "类型", Type: "yearly", Template: template},
+		{Name: "格式", Type: Services.ReportTypeDaily, Format: "docx", Template: template},
+		{Name: "调度", Type: Services.ReportTypeWeekly, Schedule: "0 8 * *", Template: template},
+		{Name: "范围", Type: Services.ReportTypeCustom, Range: "10s", Template: template},
+		{Name: "指标", Type: Services.ReportTypeDaily},
+		{Name: "邮件", Type: Services.ReportTypeDaily, Template: template, Recipients: Services.MonitoringReportRecipients{Emails: []string{"ops"}}},
+		{Name: "Webhook", Type: Services.ReportTypeDaily, Template: template, Recipients: Services.MonitoringReportRecipients{Webhooks: []string{"ftp://example.com"}}},
+	}
+	for _, input := range invalid {
+		_, err := service.CreateReport(input, 1)
+		assert.ErrorIs(t, err, Services.ErrInvalidReport, input.Name)
+	}
+
+	// 自定义报告没有调度时只能手动生成，统计范围截止到生成时间
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	report, err := service.CreateReport(Services.MonitoringReportInput{
+		Name: "近6小时", Type: Services.ReportTypeCustom, Range: "6h", Format: Services.ReportFormatJSON, Template: template,
+		Recipients: Services.MonitoringReportRecipients{Webhooks: []string{server.URL}},
+	}, 1)
+	require.NoError(t, err)
+	assert.Nil(t, report.ScheduleID)
+
+	now := time.Now()
+	file, err := service.Generate(report.ID, Services.ReportTriggerManual, now)
+	require.NoError(t, err)
+	assert.Equal(t, Services.ReportFileDeliveryFailed, file.Status)
+	assert.Contains(t, file.DeliveryError, "502")
+	assert.True(t, file.PeriodEnd.Equal(now))
+	assert.True(t, file.PeriodStart.Equal(now.Add(-6*time.Hour)))
+
+	stored, _, err := service.GetFile(report.ID, file.ID)
+	require.NoError(t, err)
+	content, err := os.ReadFile(stored.FilePath)
+	require.NoError(t, err)
+	var data Services.MonitoringReportData
+	require.NoError(t, json.Unmarshal(content, &data))
+	assert.Equal(t, "近6小时", data.Title)
+	require.Len(t, data.Metrics, 1)
+	assert.Zero(t, data.Metrics[0].Count)
+
+	// 超过保留时间的文件被清理
+	removed, err := service.Cleanup(context.Background(), now.Add(91*24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), removed)
+	_, _, err = service.GetFile(report.ID, file.ID)
+	assert.ErrorIs(t, err, Services.ErrReportFileNotFound)
+}
+
+func TestRenderMonitoringReportPDF(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	data := &Services.MonitoringReportData{
+		Title:       "月度报告",
+		PeriodStart: start,
+		PeriodEnd:   start.AddDate(0, 1, 0),
+		GeneratedAt: start.AddDate(0, 1, 0),
+		AlertCounts: map[string]int{"critical": 1},
+		Alerts:      []*Services.Alert{{Level: "critical", Status: "resolved", Message: "CPU使用率过高", CreatedAt: start.Add(time.Hour)}},
+	}
+	// 指标数量足够多时需要分页
+	for i := 0; i < 6; i++ {
+		data.Metrics = append(data.Metrics, Services.ReportMetricSummary{
+			Metric: fmt.Sprintf("metric_%d", i), Count: 2, Min: 1, Max: 3, Avg: 2, Last: 3,
+			Points: []Services.WidgetSeriesPoint{{Timestamp: start, Value: 1}, {Timestamp: start.Add(time.Hour), Value: 3}},
+		})
+	}
+
+	content, err := Services.RenderMonitoringReport(data, Services.ReportFormatPDF)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(content, []byte("%PDF-1.4")))
+	assert.True(t, bytes.HasSuffix(content, []byte("%%EOF\n")))
+	assert.Contains(t, string(content), "/BaseFont /STSong-Light")
+
+	// 交叉引用表中的偏移量指向对应的对象
+	match := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(content)
+	require.NotNil(t, match)
+	xref, err := strconv.Atoi(string(match[1]))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(content[xref:], []byte("xref\n")))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(content[xref:], -1)
+	require.Greater(t, len(entries), 6)
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		assert.True(t, bytes.HasPrefix(content[offset:], []byte(fmt.Sprintf("%d 0 obj", i+1))), "object %d", i+1)
+	}
+	pages := regexp.MustCompile(`/Count (\d+)`).FindSubmatch(content)
+	require.NotNil(t, pages)
+	count, _ := strconv.Atoi(string(pages[1]))
+	assert.Greater(t, count, 1)
+
+	_, err = Services.RenderMonitoringReport(data, "csv")
+	assert.ErrorIs(t, err, Services.ErrInvalidReport)
+}
+
+func TestMonitoringReportEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service, _, _ := setupReportService(t)
+	controller := Controllers.NewMonitoringReportController(service)
+
+	router := gin.New()
+	router.Use(func(ctx *gin.Context) {
+		ctx.Set("user_id", "1")
+		ctx.Next()
+	})
+	router.POST("/reports", controller.CreateReport)
+	router.POST("/reports/:id/generate", controller.GenerateReport)
+	router.GET("/reports/:id/files", controller.GetReportFiles)
+	router.GET("/reports/:id/files/:file_id/download", controller.DownloadReportFile)
+	request := func(method, path string, body []byte) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/reports", []byte(`{"name":"周报","type":"weekly","template":{}}`)).Code)
+	w := request(http.MethodPost, "/reports", []byte(`{"name":"周报","type":"weekly","template":{"metrics":["cpu_usage"],"include_alerts":true}}`))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"expression":"0 8 * * 1"`)
+
+	assert.Equal(t, http.StatusNotFound, request(http.MethodPost, "/reports/99/generate", nil).Code)
+	w = request(http.MethodPost, "/reports/1/generate", nil)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var generated struct {
+		Data Models.MonitoringReportFile `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &generated))
+	assert.Equal(t, Services.ReportFileGenerated, generated.Data.Status)
+
+	w = request(http.MethodGet, "/reports/1/files", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"total":1`)
+
+	w = request(http.MethodGet, fmt.Sprintf("/reports/1/files/%d/download", generated.Data.ID), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+	body, _ := io.ReadAll(w.Body)
+	assert.True(t, bytes.HasPrefix(body, []byte("%PDF")))
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/reports/1/files/99/download", nil).Code)
+}
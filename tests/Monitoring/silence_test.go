@@ -0,0 +1,304 @@
+package Monitoring
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupSilenceService(t *testing.T) (*Services.AlertSilenceService, *Services.MonitoringIncidentService, *Services.MonitoringCore, *float64, *gorm.DB) {
+	incidents, core, depth, db := setupIncidentServiceWithDB(t, time.Hour)
+	require.NoError(t, db.AutoMigrate(&Models.MonitoringMaintenanceWindow{}, &Models.MonitoringSilence{}))
+
+	config := &Config.MonitoringConfig{}
+	config.SetDefaults()
+	service := Services.NewAlertSilenceService(db, config)
+	service.Attach(core.Pipeline())
+	return service, incidents, core, depth, db
+}
+
+func externalSilenceAlert(labels map[string]string) Services.MonitoringAlert {
+	return Services.MonitoringAlert{
+		ID:       "external_1",
+		Type:     "external_alert",
+		Severity: "critical",
+		Source:   "alertmanager",
+		Metadata: map[string]interface{}{"labels": labels},
+	}
+}
+
+func TestSilenceConfigValidate(t *testing.T) {
+	config := &Config.MonitoringConfig{}
+	config.SetDefaults()
+	require.NoError(t, config.Validate())
+
+	config.Silences.SyncInterval = 0
+	assert.Error(t, config.Validate())
+	config.Silences.Enabled = false
+	assert.NoError(t, config.Validate(), "未启用时不检查")
+}
+
+func TestSilenceSuppressesPipelineNotifications(t *testing.T) {
+	service, incidents, core, depth, _ := setupSilenceService(t)
+	channel := newQueuedChannel("silence")
+	core.Pipeline().AddChannel(channel)
+
+	silence, err := service.CreateSilence(Services.SilenceInput{
+		Matchers: []Models.SilenceMatcher{{Name: "alertname", Value: "队列积压", IsEqual: true}},
+		EndsAt:   time.Now().Add(time.Hour),
+		Comment:  "队列迁移期间",
+	}, 7)
+	require.NoError(t, err)
+	assert.Equal(t, Services.SilenceStateActive, silence.Status)
+	assert.Equal(t, uint(7), silence.CreatedBy)
+
+	*depth = 20
+	require.NoError(t, core.Evaluate())
+	select {
+	case alert := <-channel.alerts:
+		t.Fatalf("被静默的告警不应发送通知: %+v", alert)
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Len(t, core.Alerts("active", 0), 1, "静默只跳过通知，告警照常触发")
+	detail, err := incidents.GetIncident(1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, detail.ActiveAlerts, "静默不影响事件记录")
+
+	silence, err = service.ExpireSilence(silence.ID)
+	require.NoError(t, err)
+	assert.Equal(t, Services.SilenceStateExpired, silence.Status)
+	_, err = service.ExpireSilence(silence.ID)
+	assert.ErrorIs(t, err, Services.ErrSilenceExpired)
+
+	*depth = 1
+	require.NoError(t, core.Evaluate())
+	notification := channel.receive(t)
+	assert.True(t, notification.Resolved, "过期后恢复通知照常发送")
+	assert.Equal(t, "队列积压", Services.SilenceLabels(notification)["alertname"])
+}
+
+func TestSilenceMatchersAndValidation(t *testing.T) {
+	service, _, _, _, _ := setupSilenceService(t)
+
+	silence, err := service.CreateSilence(Services.SilenceInput{
+		Matchers: []Models.SilenceMatcher{
+			{Name: "severity", Value: "critical|error", IsRegex: true, IsEqual: true},
+			{Name: "instance", Value: "db-2", IsEqual: false},
+			{Name: "source", Value: "alertmanager", IsEqual: true},
+		},
+		StartsAt: time.Now().Add(-time.Hour),
+		EndsAt:   time.Now().Add(time.Hour),
+		Comment:  "数据库升级",
+	}, 1)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), silence.StartsAt, time.Second, "开始时间早于当前时间时从当前时间开始")
+
+	for _, tc := range []struct {
+		labels   map[string]string
+		silenced bool
+	}{
+		{map[string]string{"severity": "critical", "instance": "db-1"}, true},
+		{map[string]string{"severity": "error"}, true},
+		{map[string]string{"severity": "critical", "instance": "db-2"}, false},
+		{map[string]string{"severity": "warning", "instance": "db-1"}, false},
+		{map[string]string{"severity": "critical2"}, false},
+	} {
+		id, ok := service.Silenced(externalSilenceAlert(tc.labels))
+		assert.Equal(t, tc.silenced, ok, tc.labels)
+		if ok {
+			assert.Equal(t, silence.ID, id)
+		}
+	}
+
+	labels := Services.SilenceLabels(Services.MonitoringAlert{
+		Type: "alert_rule", Severity: "warning", Source: "alert_service",
+		Metadata: map[string]interface{}{"rule_name": "队列积压", "metric": "queue_depth", "labels": map[string]string{"team": "ops"}},
+	})
+	assert.Equal(t, map[string]string{
+		"alertname": "队列积压", "severity": "warning", "source": "alert_service", "type": "alert_rule", "metric": "queue_depth", "team": "ops",
+	}, labels)
+
+	for _, input := range []Services.SilenceInput{
+		{Comment: "无匹配器", EndsAt: time.Now().Add(time.Hour)},
+		{Matchers: []Models.SilenceMatcher{{Name: "bad-name", Value: "x", IsEqual: true}}, Comment: "名称", EndsAt: time.Now().Add(time.Hour)},
+		{Matchers: []Models.SilenceMatcher{{Name: "job", Value: "(", IsRegex: true, IsEqual: true}}, Comment: "正则", EndsAt: time.Now().Add(time.Hour)},
+		{Matchers: []Models.SilenceMatcher{{Name: "job", Value: "api", IsEqual: false}}, Comment: "匹配空值", EndsAt: time.Now().Add(time.Hour)},
+		{Matchers: []Models.SilenceMatcher{{Name: "job", Value: "api", IsEqual: true}}, Comment: "已结束", EndsAt: time.Now().Add(-time.Minute)},
+		{Matchers: []Models.SilenceMatcher{{Name: "job", Value: "api", IsEqual: true}}, Comment: " ", EndsAt: time.Now().Add(time.Hour)},
+	} {
+		_, err := service.CreateSilence(input, 1)
+		assert.ErrorIs(t, err, Services.ErrInvalidSilence, input.Comment)
+	}
+}
+
+func TestMaintenanceWindowCreatesLinkedSilences(t *testing.T) {
+	service, _, _, _, db := setupSilenceService(t)
+	now := time.Date(2024, 1, 1, 1, 30, 0, 0, time.Local)
+	service.SetClock(func() time.Time { return now })
+	alert := externalSilenceAlert(map[string]string{"alertname": "DiskFull", "env": "staging"})
+
+	window, err := service.CreateMaintenanceWindow(Services.MaintenanceWindowInput{
+		Name:            "每日备份",
+		Matchers:        []Models.SilenceMatcher{{Name: "env", Value: "staging", IsEqual: true}},
+		Schedule:        "0 2 * * *",
+		DurationMinutes: 60,
+	}, 3)
+	require.NoError(t, err)
+
+	linked := func() []Models.MonitoringSilence {
+		var silences []Models.MonitoringSilence
+		require.NoError(t, db.Where("maintenance_window_id = ?", window.ID).Order("starts_at").Find(&silences).Error)
+		return silences
+	}
+	silences := linked()
+	require.Len(t, silences, 1)
+	assert.True(t, silences[0].StartsAt.Equal(time.Date(2024, 1, 1, 2, 0, 0, 0, time.Local)))
+	assert.True(t, silences[0].EndsAt.Equal(time.Date(2024, 1, 1, 3, 0, 0, 0, time.Local)))
+	assert.Equal(t, uint(3), silences[0].CreatedBy)
+	_, silenced := service.Silenced(alert)
+	assert.False(t, silenced, "维护窗口未开始")
+
+	require.NoError(t, service.SyncMaintenanceWindows())
+	assert.Len(t, linked(), 1, "同一时段不重复创建")
+
+	now = time.Date(2024, 1, 1, 2, 30, 0, 0, time.Local)
+	_, silenced = service.Silenced(alert)
+	assert.True(t, silenced)
+
+	// 上一时段结束后创建下一时段
+	now = time.Date(2024, 1, 1, 3, 30, 0, 0, time.Local)
+	require.NoError(t, service.SyncMaintenanceWindows())
+	silences = linked()
+	require.Len(t, silences, 2)
+	assert.True(t, silences[1].StartsAt.Equal(time.Date(2024, 1, 2, 2, 0, 0, 0, time.Local)))
+
+	// 手动过期的时段不会重新创建
+	expired, err := service.ExpireSilence(silences[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, Services.SilenceStateExpired, expired.Status)
+	require.NoError(t, service.SyncMaintenanceWindows())
+	assert.Len(t, linked(), 2)
+
+	startsAt, endsAt := now.Add(-10*time.Minute), now.Add(10*time.Minute)
+	oneOff, err := service.CreateMaintenanceWindow(Services.MaintenanceWindowInput{
+		Name:     "数据库迁移",
+		Matchers: []Models.SilenceMatcher{{Name: "alertname", Value: "Disk.*", IsRegex: true, IsEqual: true}},
+		StartsAt: &startsAt,
+		EndsAt:   &endsAt,
+	}, 3)
+	require.NoError(t, err)
+	_, silenced = service.Silenced(alert)
+	assert.True(t, silenced, "单次窗口进行中时立即静默")
+
+	require.NoError(t, service.DeleteMaintenanceWindow(oneOff.ID))
+	_, silenced = service.Silenced(alert)
+	assert.False(t, silenced, "删除窗口后关联的静默过期")
+	_, err = service.GetMaintenanceWindow(oneOff.ID)
+	assert.ErrorIs(t, err, Services.ErrMaintenanceWindowNotFound)
+
+	for _, input := range []Services.MaintenanceWindowInput{
+		{Name: "错误计划", Matchers: []Models.SilenceMatcher{{Name: "env", Value: "x", IsEqual: true}}, Schedule: "61 * * * *", DurationMinutes: 10},
+		{Name: "缺少时长", Matchers: []Models.SilenceMatcher{{Name: "env", Value: "x", IsEqual: true}}, Schedule: "0 2 * * *"},
+		{Name: "缺少时间", Matchers: []Models.SilenceMatcher{{Name: "env", Value: "x", IsEqual: true}}, StartsAt: &startsAt},
+	} {
+		_, err := service.CreateMaintenanceWindow(input, 3)
+		assert.ErrorIs(t, err, Services.ErrInvalidMaintenanceWindow, input.Name)
+	}
+}
+
+func TestSilenceEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service, _, _, _, _ := setupSilenceService(t)
+	controller := Controllers.NewMonitoringSilenceController(service)
+	router := gin.New()
+	router.Use(func(ctx *gin.Context) { ctx.Set("user_id", uint(5)) })
+	router.GET("/silences", controller.GetSilences)
+	router.POST("/silences", controller.CreateSilence)
+	router.GET("/silences/:id", controller.GetSilence)
+	router.DELETE("/silences/:id", controller.ExpireSilence)
+	router.GET("/maintenance-windows", controller.GetMaintenanceWindows)
+	router.POST("/maintenance-windows", controller.CreateMaintenanceWindow)
+	router.DELETE("/maintenance-windows/:id", controller.DeleteMaintenanceWindow)
+	request := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(http.MethodPost, "/silences", map[string]interface{}{
+		"matchers": []map[string]interface{}{{"name": "alertname", "value": "DiskFull", "is_equal": true}},
+		"ends_at":  time.Now().Add(time.Hour),
+		"comment":  "磁盘扩容",
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Data Models.MonitoringSilence `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, uint(5), created.Data.CreatedBy)
+
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/silences", map[string]interface{}{
+		"matchers": []map[string]interface{}{{"name": "job", "value": ".*", "is_regex": true, "is_equal": true}},
+		"ends_at":  time.Now().Add(time.Hour),
+		"comment":  "全部静默",
+	}).Code)
+
+	var list struct {
+		Data []Models.MonitoringSilence `json:"data"`
+	}
+	w = request(http.MethodGet, "/silences?state=active", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, Services.SilenceStateActive, list.Data[0].Status)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodGet, "/silences?state=unknown", nil).Code)
+
+	assert.Equal(t, http.StatusOK, request(http.MethodDelete, "/silences/1", nil).Code)
+	assert.Equal(t, http.StatusConflict, request(http.MethodDelete, "/silences/1", nil).Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/silences/99", nil).Code)
+	w = request(http.MethodGet, "/silences?state=expired", nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Len(t, list.Data, 1)
+
+	w = request(http.MethodPost, "/maintenance-windows", map[string]interface{}{
+		"name":             "每周发布",
+		"matchers":         []map[string]interface{}{{"name": "env", "value": "prod", "is_equal": true}},
+		"schedule":         "0 22 * * 4",
+		"duration_minutes": 120,
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/maintenance-windows", map[string]interface{}{
+		"name":     "缺少时段",
+		"matchers": []map[string]interface{}{{"name": "env", "value": "prod", "is_equal": true}},
+	}).Code)
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/maintenance-windows", nil).Code)
+
+	w = request(http.MethodGet, "/silences?filter[maintenance_window_id]=1", nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, Services.SilenceStatePending, list.Data[0].Status)
+
+	assert.Equal(t, http.StatusOK, request(http.MethodDelete, "/maintenance-windows/1", nil).Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodDelete, "/maintenance-windows/1", nil).Code)
+	w = request(http.MethodGet, "/silences?state=pending", nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Empty(t, list.Data, "删除窗口后关联的静默过期")
+}
@@ -0,0 +1,292 @@
+package Monitoring
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupSLOService(t *testing.T) (*Services.MonitoringSLOService, *Services.MonitoringCore, *Services.MetricHistoryService) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "slos.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.MonitoringSLO{}))
+
+	config := &Config.MonitoringConfig{}
+	config.SetDefaults()
+	require.NoError(t, config.Validate())
+
+	core := newTestMonitoringCore()
+	history := setupMetricHistory(t)
+	service := Services.NewMonitoringSLOService(db, config)
+	service.SetMonitoringCore(core)
+	service.SetMetricHistory(history)
+	return service, core, history
+}
+
+// seedSLOSamples 截止到 end 每分钟写入一个采样，value 按采样距 end 的分钟数返回取值
+func seedSLOSamples(t *testing.T, history *Services.MetricHistoryService, name string, end time.Time, count int, value func(minutesAgo int) float64) {
+	samples := make([]Models.MetricSample, 0, count)
+	for i := count - 1; i >= 0; i-- {
+		samples = append(samples, Models.MetricSample{Name: name, Value: value(i), Timestamp: end.Add(-time.Duration(i) * time.Minute)})
+	}
+	require.NoError(t, history.RecordBatch(samples))
+}
+
+func floatPtr(value float64) *float64 {
+	return &value
+}
+
+func TestSLOComplianceAndErrorBudget(t *testing.T) {
+	service, _, history := setupSLOService(t)
+	now := time.Now()
+
+	// 1000个采样中12小时前有5个失败：达成率99.5%，99%目标的预算消耗一半，近期没有消耗
+	seedSLOSamples(t, history, "api_up", now, 1000, func(minutesAgo int) float64 {
+		if minutesAgo >= 800 && minutesAgo < 805 {
+			return 0
+		}
+		return 1
+	})
+	availability, err := service.CreateSLO(Services.SLOInput{
+		Name: "API可用性", Type: Services.SLOTypeAvailability, Metric: "api_up",
+		Threshold: floatPtr(1), Objective: 99, WindowDays: 1,
+	}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, ">=", availability.Comparison)
+
+	status, err := service.Status(availability.ID, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1000, status.TotalSamples)
+	assert.Equal(t, 995, status.GoodSamples)
+	assert.InDelta(t, 99.5, status.Compliance, 1e-9)
+	assert.InDelta(t, 50, status.BudgetRemaining, 1e-9)
+	assert.True(t, status.Met)
+	assert.Nil(t, status.Percentile)
+	assert.Equal(t, 0.0, status.BurnRates["1h"])
+	assert.Equal(t, 0.0, status.BurnRates["6h"])
+	assert.False(t, status.FastBurn)
+	assert.False(t, status.SlowBurn)
+
+	// 延迟取值1-100：不超过95的采样占95%，目标90时的P90为90
+	seedSLOSamples(t, history, "api_latency_ms", now, 100, func(minutesAgo int) float64 {
+		return float64(100 - minutesAgo)
+	})
+	latency, err := service.CreateSLO(Services.SLOInput{
+		Name: "API延迟", Type: Services.SLOTypeLatency, Metric: "api_latency_ms",
+		Threshold: floatPtr(95), Objective: 90,
+	}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "<=", latency.Comparison)
+	assert.Equal(t, 30, latency.WindowDays)
+
+	status, err = service.Status(latency.ID, now)
+	require.NoError(t, err)
+	assert.InDelta(t, 95, status.Compliance, 1e-9)
+	require.NotNil(t, status.Percentile)
+	assert.Equal(t, 90.0, *status.Percentile)
+	assert.InDelta(t, 50, status.BudgetRemaining, 1e-9)
+
+	// 没有采样时视为达标
+	empty, err := service.CreateSLO(Services.SLOInput{
+		Name: "任务成功率", Type: Services.SLOTypeAvailability, Metric: "job_success", Threshold: floatPtr(1), Objective: 99.9,
+	}, 1)
+	require.NoError(t, err)
+	status, err = service.Status(empty.ID, now)
+	require.NoError(t, err)
+	assert.True(t, status.NoData)
+	assert.True(t, status.Met)
+	assert.Equal(t, 100.0, status.BudgetRemaining)
+
+	statuses, err := service.Statuses(now)
+	require.NoError(t, err)
+	assert.Len(t, statuses, 3)
+}
+
+func TestSLOValidation(t *testing.T) {
+	service, _, _ := setupSLOService(t)
+
+	invalid := []Services.SLOInput{
+		{Name: "a", Type: "throughput", Metric: "m", Threshold: floatPtr(1), Objective: 99},
+		{Name: "a", Type: Services.SLOTypeAvailability, Metric: "m", Objective: 99},
+		{Name: "a", Type: Services.SLOTypeAvailability, Metric: "m", Threshold: floatPtr(1), Objective: 100},
+		{Name: "a", Type: Services.SLOTypeAvailability, Metric: "m", Comparison: "==", Threshold: floatPtr(1), Objective: 99},
+		{Name: "a", Type: Services.SLOTypeLatency, Metric: "m", Comparison: ">=", Threshold: floatPtr(1), Objective: 99},
+		{Name: "a", Type: Services.SLOTypeAvailability, Metric: "m", Threshold: floatPtr(1), Objective: 99, WindowDays: 91},
+	}
+	for _, input := range invalid {
+		_, err := service.CreateSLO(input, 1)
+		assert.ErrorIs(t, err, Services.ErrInvalidSLO, "%+v", input)
+	}
+
+	input := Services.SLOInput{Name: "可用性", Type: Services.SLOTypeAvailability, Metric: "up", Threshold: floatPtr(1), Objective: 99}
+	_, err := service.CreateSLO(input, 1)
+	require.NoError(t, err)
+	_, err = service.CreateSLO(input, 1)
+	assert.ErrorIs(t, err, Services.ErrSLONameExists)
+	_, err = service.GetSLO(99)
+	assert.ErrorIs(t, err, Services.ErrSLONotFound)
+}
+
+func TestSLOBurnRateAlerts(t *testing.T) {
+	service, core, history := setupSLOService(t)
+	now := time.Now()
+
+	disabled := false
+	slo, err := service.CreateSLO(Services.SLOInput{
+		Name: "下单成功率", Type: Services.SLOTypeAvailability, Metric: "order_success",
+		Threshold: floatPtr(1), Objective: 99, Enabled: &disabled,
+	}, 1)
+	require.NoError(t, err)
+	assert.False(t, slo.Enabled)
+
+	// 规则注册到统一告警引擎，停用的SLO规则也停用
+	rules := map[string]*Services.AlertRule{}
+	for _, rule := range core.Rules() {
+		rules[rule.ID] = rule
+	}
+	fast, slow := rules["slo_1_fast_burn"], rules["slo_1_slow_burn"]
+	require.NotNil(t, fast)
+	require.NotNil(t, slow)
+	assert.Equal(t, Services.AlertLevelCritical, fast.Level)
+	assert.Equal(t, Services.AlertLevelWarning, slow.Level)
+	assert.Equal(t, 14.4, fast.Threshold)
+	assert.Equal(t, 6.0, slow.Threshold)
+	assert.False(t, fast.Enabled)
+	assert.Empty(t, service.Evaluate(now))
+
+	// 最近一小时后15分钟全部失败：1小时窗口消耗速率25，5分钟窗口100
+	seedSLOSamples(t, history, "order_success", now, 60, func(minutesAgo int) float64 {
+		if minutesAgo < 15 {
+			return 0
+		}
+		return 1
+	})
+	enabled := true
+	_, err = service.UpdateSLO(slo.ID, Services.SLOInput{
+		Name: "下单成功率", Type: Services.SLOTypeAvailability, Metric: "order_success",
+		Threshold: floatPtr(1), Objective: 99, Enabled: &enabled,
+	})
+	require.NoError(t, err)
+
+	statuses := service.Evaluate(now)
+	require.Len(t, statuses, 1)
+	assert.InDelta(t, 25, statuses[0].BurnRates["1h"], 1e-9)
+	assert.InDelta(t, 100, statuses[0].BurnRates["5m"], 1e-9)
+	assert.True(t, statuses[0].FastBurn)
+	assert.True(t, statuses[0].SlowBurn)
+	assert.False(t, statuses[0].Met)
+
+	values := core.Values()
+	assert.InDelta(t, 25, values["slo_1_burn_rate_fast"], 1e-9)
+	assert.InDelta(t, 25, values["slo_1_burn_rate_slow"], 1e-9)
+	assert.InDelta(t, 75, values["slo_1_compliance"], 1e-9)
+
+	require.NoError(t, core.CheckAlerts())
+	active := core.Alerts("active", 0)
+	require.Len(t, active, 2)
+	ruleIDs := []string{active[0].RuleID, active[1].RuleID}
+	assert.ElementsMatch(t, []string{"slo_1_fast_burn", "slo_1_slow_burn"}, ruleIDs)
+
+	// 删除SLO移除规则并解决未恢复的告警
+	require.NoError(t, service.DeleteSLO(slo.ID))
+	assert.Empty(t, core.Alerts("active", 0))
+	for _, rule := range core.Rules() {
+		assert.NotContains(t, rule.ID, "slo_")
+	}
+}
+
+func TestMonitoringReportSLOSection(t *testing.T) {
+	reportService, _, _ := setupReportService(t)
+	sloService, _, history := setupSLOService(t)
+	reportService.SetSLOService(sloService)
+
+	now := time.Now()
+	seedSLOSamples(t, history, "api_up", now, 100, func(minutesAgo int) float64 { return 1 })
+	_, err := sloService.CreateSLO(Services.SLOInput{
+		Name: "API可用性", Type: Services.SLOTypeAvailability, Metric: "api_up", Threshold: floatPtr(1), Objective: 99.9,
+	}, 1)
+	require.NoError(t, err)
+
+	// 只包含SLO的报告不需要指标
+	report, err := reportService.CreateReport(Services.MonitoringReportInput{
+		Name: "SLO日报", Type: Services.ReportTypeCustom, Format: Services.ReportFormatHTML, Range: "1h",
+		Template: Services.MonitoringReportTemplate{IncludeSLOs: true},
+	}, 1)
+	require.NoError(t, err)
+
+	data, err := reportService.BuildReportData(report, now)
+	require.NoError(t, err)
+	require.Len(t, data.SLOs, 1)
+	assert.Equal(t, "API可用性", data.SLOs[0].Name)
+	assert.Equal(t, 100.0, data.SLOs[0].Compliance)
+
+	html, err := Services.RenderMonitoringReport(data, Services.ReportFormatHTML)
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "服务等级目标")
+	assert.Contains(t, string(html), "<td>99.9%</td>")
+	assert.Contains(t, string(html), "<td>达标</td>")
+
+	pdf, err := Services.RenderMonitoringReport(data, Services.ReportFormatPDF)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF")))
+}
+
+func TestSLOEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service, _, _ := setupSLOService(t)
+	controller := Controllers.NewMonitoringSLOController(service)
+
+	router := gin.New()
+	router.Use(func(ctx *gin.Context) {
+		ctx.Set("user_id", "1")
+		ctx.Next()
+	})
+	router.GET("/slos", controller.GetSLOs)
+	router.POST("/slos", controller.CreateSLO)
+	router.GET("/slos/status", controller.GetSLOStatuses)
+	router.GET("/slos/:id", controller.GetSLO)
+	router.DELETE("/slos/:id", controller.DeleteSLO)
+	router.GET("/slos/:id/status", controller.GetSLOStatus)
+	request := func(method, path string, body []byte) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	body := []byte(`{"name":"API可用性","type":"availability","metric":"api_up","threshold":1,"objective":99.9}`)
+	w := request(http.MethodPost, "/slos", body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"window_days":30`)
+	assert.Equal(t, http.StatusConflict, request(http.MethodPost, "/slos", body).Code)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/slos", []byte(`{"name":"x","type":"availability","metric":"up","threshold":1,"objective":120}`)).Code)
+
+	w = request(http.MethodGet, "/slos/status", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"no_data":true`)
+
+	w = request(http.MethodGet, "/slos/1/status", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"burn_rates"`)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/slos/99/status", nil).Code)
+
+	assert.Equal(t, http.StatusOK, request(http.MethodDelete, "/slos/1", nil).Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/slos/1", nil).Code)
+}
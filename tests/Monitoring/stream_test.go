@@ -0,0 +1,180 @@
+package Monitoring
+
+import (
+	"bufio"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Services"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receiveStreamEvent(t *testing.T, events <-chan Services.MonitoringStreamEvent) Services.MonitoringStreamEvent {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("未收到实时监控事件")
+		return Services.MonitoringStreamEvent{}
+	}
+}
+
+func TestMonitoringStreamFilter(t *testing.T) {
+	_, err := Services.ParseMonitoringStreamFilter("metrics,logs", "")
+	assert.ErrorIs(t, err, Services.ErrUnknownMonitoringTopic)
+	_, err = Services.ParseMonitoringStreamFilter("", "cpu_[")
+	assert.ErrorIs(t, err, Services.ErrInvalidMonitoringPattern)
+
+	filter, err := Services.ParseMonitoringStreamFilter(" metrics ,", "cpu_usage, memory_*")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"metrics"}, filter.Topics)
+
+	event, ok := filter.Apply(Services.MonitoringStreamEvent{
+		Topic:   Services.MonitoringStreamTopicMetrics,
+		Metrics: map[string]float64{"cpu_usage": 10, "memory_usage": 20, "memory_heap": 5, "goroutines": 30},
+	})
+	require.True(t, ok)
+	assert.Equal(t, map[string]float64{"cpu_usage": 10, "memory_usage": 20, "memory_heap": 5}, event.Metrics)
+
+	_, ok = filter.Apply(Services.MonitoringStreamEvent{Topic: Services.MonitoringStreamTopicMetrics, Metrics: map[string]float64{"goroutines": 30}})
+	assert.False(t, ok, "没有匹配的指标时不推送")
+	_, ok = filter.Apply(Services.MonitoringStreamEvent{Topic: Services.MonitoringStreamTopicAlerts, Alert: &Services.Alert{Metric: "cpu_usage"}})
+	assert.False(t, ok, "未订阅的主题不推送")
+
+	// 告警按规则涉及的任一指标匹配
+	filter, err = Services.ParseMonitoringStreamFilter("", "memory_*")
+	require.NoError(t, err)
+	_, ok = filter.Apply(Services.MonitoringStreamEvent{Topic: Services.MonitoringStreamTopicAlerts, Alert: &Services.Alert{Metric: "cpu_usage,memory_usage"}})
+	assert.True(t, ok)
+	_, ok = filter.Apply(Services.MonitoringStreamEvent{Topic: Services.MonitoringStreamTopicAlerts, Alert: &Services.Alert{Metric: "cpu_usage"}})
+	assert.False(t, ok)
+}
+
+func TestMonitoringCorePublishesMetricsAndAlertTransitions(t *testing.T) {
+	core := newTestMonitoringCore()
+	depth := 20.0
+	require.NoError(t, core.RegisterCollector(Services.NewMetricCollector("queue", func(ctx context.Context) (map[string]float64, error) {
+		return map[string]float64{"queue_depth": depth}, nil
+	})))
+	require.NoError(t, core.AddRule(&Services.AlertRule{
+		ID: "queue_backlog", Name: "队列积压", Metric: "queue_depth", Condition: ">", Threshold: 10,
+		Level: Services.AlertLevelError, Enabled: true,
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	metrics := core.Stream().Subscribe(ctx, Services.MonitoringStreamFilter{Topics: []string{"metrics"}, Metrics: []string{"queue_*"}})
+	alerts := core.Stream().Subscribe(ctx, Services.MonitoringStreamFilter{Topics: []string{"alerts"}})
+
+	require.NoError(t, core.Evaluate())
+	event := receiveStreamEvent(t, metrics)
+	assert.Equal(t, map[string]float64{"queue_depth": 20}, event.Metrics)
+	event = receiveStreamEvent(t, alerts)
+	assert.Equal(t, Services.AlertTransitionTriggered, event.Transition)
+	require.NotNil(t, event.Alert)
+	assert.Equal(t, "queue_backlog", event.Alert.RuleID)
+	assert.Equal(t, "active", event.Alert.Status)
+
+	// 同一告警重复触发不产生状态变化事件
+	require.NoError(t, core.Evaluate())
+	receiveStreamEvent(t, metrics)
+	assert.Empty(t, alerts)
+
+	depth = 1
+	require.NoError(t, core.Evaluate())
+	event = receiveStreamEvent(t, alerts)
+	assert.Equal(t, Services.AlertTransitionResolved, event.Transition)
+	assert.Equal(t, "resolved", event.Alert.Status)
+	assert.NotNil(t, event.Alert.ResolvedAt)
+
+	// 取消订阅后通道关闭
+	cancel()
+	require.Eventually(t, func() bool {
+		return core.Stream().Stats()["subscribers"] == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestMonitoringStreamDropsEventsForSlowSubscribers(t *testing.T) {
+	stream := Services.NewMonitoringStream()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := stream.Subscribe(ctx, Services.MonitoringStreamFilter{})
+
+	for i := 0; i < 100; i++ {
+		stream.PublishMetrics(map[string]float64{"cpu_usage": float64(i)})
+	}
+	assert.Equal(t, 32, len(events))
+	assert.Equal(t, uint64(68), stream.Stats()["dropped"])
+	assert.Equal(t, uint64(1), receiveStreamEvent(t, events).ID)
+}
+
+func TestMonitoringControllerStreamEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	core := newTestMonitoringCore()
+	service := Services.NewOptimizedMonitoringService()
+	service.SetMonitoringCore(core)
+	core.Observe("queue_depth", 3)
+
+	controller := Controllers.NewMonitoringController()
+	controller.SetMonitoringService(service)
+	router := gin.New()
+	router.GET("/api/v1/monitoring/stream", controller.StreamEvents)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/v1/monitoring/stream?topics=unknown")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/monitoring/stream?metrics=queue_depth", nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent := func() (string, Services.MonitoringStreamEvent) {
+		var name string
+		var event Services.MonitoringStreamEvent
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			line = strings.TrimSpace(line)
+			switch {
+			case strings.HasPrefix(line, "event:"):
+				name = strings.TrimPrefix(line, "event:")
+			case strings.HasPrefix(line, "data:"):
+				require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &event))
+			case line == "" && name != "":
+				return name, event
+			}
+		}
+	}
+
+	// 连接建立后先推送最新指标值
+	name, event := readEvent()
+	assert.Equal(t, "metrics", name)
+	assert.Equal(t, map[string]float64{"queue_depth": 3}, event.Metrics)
+
+	require.Eventually(t, func() bool {
+		return core.Stream().Stats()["subscribers"] == 1
+	}, time.Second, 10*time.Millisecond)
+	core.Observe("queue_depth", 7)
+	core.Collect()
+	name, event = readEvent()
+	assert.Equal(t, "metrics", name)
+	assert.Equal(t, map[string]float64{"queue_depth": 7}, event.Metrics)
+	assert.NotZero(t, event.ID)
+}
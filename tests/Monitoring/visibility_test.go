@@ -0,0 +1,292 @@
+package Monitoring
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Routes"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"cloud-platform-api/app/Testing"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestVisibility(t *testing.T) *Services.MonitoringVisibility {
+	config := &Config.MonitoringConfig{}
+	config.SetDefaults()
+	config.Visibility.MetricSensitivity = "revenue_*=confidential, security_*=restricted, business=confidential"
+	config.Visibility.RoleClearance = "auditor=confidential,guest=public"
+	require.NoError(t, config.Visibility.Validate())
+
+	visibility := Services.NewMonitoringVisibility(&config.Visibility)
+	Services.SetDefaultMonitoringVisibility(visibility)
+	t.Cleanup(func() { Services.SetDefaultMonitoringVisibility(nil) })
+	return visibility
+}
+
+func TestMonitoringVisibilityLevels(t *testing.T) {
+	visibility := newTestVisibility(t)
+	user := Services.DashboardViewer{UserID: 1, Role: "user"}
+	auditor := Services.DashboardViewer{UserID: 2, Role: "auditor"}
+	guest := Services.DashboardViewer{UserID: 3, Role: "guest"}
+	admin := Services.DashboardViewer{UserID: 9, Role: "admin"}
+
+	assert.Equal(t, "confidential", visibility.Sensitivity("revenue_total"))
+	assert.Equal(t, "internal", visibility.Sensitivity("cpu_usage"))
+	assert.Equal(t, "internal", visibility.Clearance("user"))
+	assert.Equal(t, "restricted", visibility.Clearance("admin"))
+
+	assert.True(t, visibility.MetricVisible("cpu_usage", user))
+	assert.False(t, visibility.MetricVisible("revenue_total", user))
+	assert.True(t, visibility.MetricVisible("revenue_total", auditor))
+	assert.False(t, visibility.MetricVisible("security_failed_logins", auditor))
+	assert.True(t, visibility.MetricVisible("security_failed_logins", admin))
+	assert.False(t, visibility.MetricVisible("cpu_usage", guest), "guest 只能查看 public 指标")
+	assert.Equal(t, []string{"cpu_usage"}, visibility.VisibleMetrics([]string{"cpu_usage", "revenue_total"}, user))
+
+	// 组合条件告警涉及的指标全部可见时才可见
+	assert.True(t, visibility.AlertVisible(&Services.Alert{Metric: "cpu_usage"}, user))
+	assert.False(t, visibility.AlertVisible(&Services.Alert{Metric: "cpu_usage,revenue_total"}, user))
+	assert.Nil(t, visibility.AlertFilter(admin))
+	assert.Nil(t, visibility.MetricFilter(admin))
+
+	teamID := uint(7)
+	teamRule := &Models.AlertRule{MetricName: "cpu_usage", CreatedBy: 1, TeamID: &teamID, Visibility: Models.AlertRuleVisibilityTeam}
+	assert.True(t, visibility.RuleVisible(teamRule, user), "创建者可见")
+	assert.False(t, visibility.RuleVisible(teamRule, auditor))
+	assert.True(t, visibility.RuleVisible(teamRule, Services.DashboardViewer{UserID: 4, Role: "user", Teams: map[uint]string{7: Models.TeamRoleMember}}))
+	assert.True(t, visibility.RuleVisible(teamRule, admin))
+	publicRule := &Models.AlertRule{MetricName: "revenue_total", CreatedBy: 1, Visibility: Models.AlertRuleVisibilityPublic}
+	assert.True(t, visibility.RuleVisible(publicRule, auditor))
+	assert.False(t, visibility.RuleVisible(publicRule, Services.DashboardViewer{UserID: 5, Role: "user"}), "公开规则的指标不可见时规则也不可见")
+
+	// 实时事件流只推送可见的指标和告警
+	filter := Services.MonitoringStreamFilter{Visible: visibility.MetricFilter(user)}
+	event, ok := filter.Apply(Services.MonitoringStreamEvent{
+		Topic:   Services.MonitoringStreamTopicMetrics,
+		Metrics: map[string]float64{"cpu_usage": 10, "revenue_total": 1000},
+	})
+	require.True(t, ok)
+	assert.Equal(t, map[string]float64{"cpu_usage": 10}, event.Metrics)
+	_, ok = filter.Apply(Services.MonitoringStreamEvent{Topic: Services.MonitoringStreamTopicAlerts, Alert: &Services.Alert{Metric: "revenue_total"}})
+	assert.False(t, ok)
+
+	invalid := Config.MonitoringVisibilityConfig{DefaultSensitivity: "internal", DefaultClearance: "internal", MetricSensitivity: "revenue_*=secret"}
+	assert.Error(t, invalid.Validate())
+	invalid = Config.MonitoringVisibilityConfig{DefaultSensitivity: "internal", DefaultClearance: "top"}
+	assert.Error(t, invalid.Validate())
+}
+
+func TestMonitoringEndpointsFilterByRole(t *testing.T) {
+	newTestVisibility(t)
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "visibility.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.AlertRule{}, &Models.TeamMember{}))
+	previous := Database.DB
+	Database.DB = db
+	t.Cleanup(func() { Database.DB = previous })
+
+	core := newTestMonitoringCore()
+	require.NoError(t, core.RegisterCollector(Services.NewMetricCollector("business", func(ctx context.Context) (map[string]float64, error) {
+		return map[string]float64{"revenue_total": 5, "queue_depth": 20}, nil
+	})))
+	for _, metric := range []string{"revenue_total", "queue_depth"} {
+		require.NoError(t, core.AddRule(&Services.AlertRule{
+			ID: metric + "_high", Name: metric, Metric: metric, Condition: ">", Threshold: 1,
+			Level: Services.AlertLevelWarning, Enabled: true,
+		}))
+	}
+	require.NoError(t, core.Evaluate())
+	service := Services.NewOptimizedMonitoringService()
+	service.SetMonitoringCore(core)
+
+	teamID := uint(3)
+	require.NoError(t, db.Create(&Models.TeamMember{TeamID: teamID, UserID: 2, Role: Models.TeamRoleMember}).Error)
+	rules := []Models.AlertRule{
+		{Name: "队列", Type: "threshold", MetricType: "application", MetricName: "queue_depth", Condition: ">", Threshold: 10, Severity: "warning", CreatedBy: 1},
+		{Name: "收入", Type: "threshold", MetricType: "business", MetricName: "revenue_total", Condition: "<", Threshold: 1, Severity: "warning", CreatedBy: 1},
+		{Name: "团队", Type: "threshold", MetricType: "application", MetricName: "queue_depth", Condition: ">", Threshold: 50, Severity: "critical", CreatedBy: 1, TeamID: &teamID, Visibility: Models.AlertRuleVisibilityTeam},
+	}
+	require.NoError(t, db.Create(&rules).Error)
+	var stored Models.AlertRule
+	require.NoError(t, db.First(&stored, rules[0].ID).Error)
+	assert.Equal(t, Models.AlertRuleVisibilityPublic, stored.Visibility, "未指定时为 public")
+
+	controller := Controllers.NewMonitoringController()
+	controller.SetMonitoringService(service)
+	engine := gin.New()
+	engine.Use(func(ctx *gin.Context) {
+		id, _ := strconv.ParseUint(ctx.GetHeader("X-User"), 10, 32)
+		ctx.Set("user_id", uint(id))
+		ctx.Set("user_role", ctx.GetHeader("X-Role"))
+	})
+	engine.GET("/metrics", controller.GetMetrics)
+	engine.GET("/alerts", controller.GetAlerts)
+	engine.GET("/alert-rules", controller.GetAlertRules)
+	engine.PUT("/alert-rules/:id", controller.UpdateAlertRule)
+	do := func(method, path, user, role string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User", user)
+		req.Header.Set("X-Role", role)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	alertMetrics := func(user, role string) []string {
+		w := do(http.MethodGet, "/alerts", user, role)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Data []Services.Alert `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		metrics := make([]string, 0, len(resp.Data))
+		for _, alert := range resp.Data {
+			metrics = append(metrics, alert.Metric)
+		}
+		return metrics
+	}
+	assert.Equal(t, []string{"queue_depth"}, alertMetrics("1", "user"))
+	assert.ElementsMatch(t, []string{"queue_depth", "revenue_total"}, alertMetrics("2", "auditor"))
+	assert.ElementsMatch(t, []string{"queue_depth", "revenue_total"}, alertMetrics("9", "admin"))
+
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/metrics?type=business", "1", "user").Code)
+	assert.NotEqual(t, http.StatusForbidden, do(http.MethodGet, "/metrics?type=business", "2", "auditor").Code)
+
+	ruleNames := func(user, role string) []string {
+		w := do(http.MethodGet, "/alert-rules", user, role)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Data struct {
+				AlertRules []Models.AlertRule `json:"alert_rules"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		names := make([]string, 0, len(resp.Data.AlertRules))
+		for _, rule := range resp.Data.AlertRules {
+			names = append(names, rule.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"队列"}, ruleNames("5", "user"))
+	assert.Equal(t, []string{"队列", "团队"}, ruleNames("2", "user"), "团队成员可见团队规则")
+	assert.Equal(t, []string{"队列", "收入", "团队"}, ruleNames("1", "guest"), "创建者可见自己的规则")
+	assert.Equal(t, []string{"队列", "收入", "团队"}, ruleNames("9", "admin"))
+
+	// 不可见的规则按不存在处理
+	path := "/alert-rules/" + strconv.FormatUint(uint64(rules[2].ID), 10)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPut, path, "5", "user").Code)
+}
+
+// TestAlertRuleVisibilityThroughRegisteredRoutes 应用实际注册的告警规则路由按角色、团队和指标敏感级别过滤
+func TestAlertRuleVisibilityThroughRegisteredRoutes(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-jwt-secret-key-for-testing-only-32-chars")
+	t.Setenv("LOG_BASE_PATH", t.TempDir())
+	t.Setenv("MONITORING_VISIBILITY_METRIC_SENSITIVITY", "revenue_*=confidential")
+	Config.LoadConfig()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "visibility.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.User{}, &Models.AlertRule{}, &Models.Team{}, &Models.TeamMember{}))
+	previous := Database.DB
+	Database.DB = db
+	t.Cleanup(func() {
+		Database.DB = previous
+		Services.SetDefaultMonitoringVisibility(nil)
+		OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "Cloud Platform API", Version: "1.0.0"}))
+	})
+
+	factory := Testing.NewFactory(t, db)
+	creator, member, outsider, admin := factory.User(), factory.User(), factory.User(), factory.Admin()
+	teamID := uint(3)
+	require.NoError(t, db.Create(&Models.TeamMember{TeamID: teamID, UserID: member.ID, Role: Models.TeamRoleMember}).Error)
+	rules := []Models.AlertRule{
+		{Name: "队列", Type: "threshold", MetricType: "application", MetricName: "queue_depth", Condition: ">", Threshold: 10, Severity: "warning", CreatedBy: creator.ID},
+		{Name: "收入", Type: "threshold", MetricType: "business", MetricName: "revenue_total", Condition: "<", Threshold: 1, Severity: "warning", CreatedBy: creator.ID},
+		{Name: "团队", Type: "threshold", MetricType: "application", MetricName: "queue_depth", Condition: ">", Threshold: 50, Severity: "critical", CreatedBy: creator.ID, TeamID: &teamID, Visibility: Models.AlertRuleVisibilityTeam},
+	}
+	require.NoError(t, db.Create(&rules).Error)
+
+	OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "测试", Version: "1.0.0"}))
+	engine := gin.New()
+	Routes.RegisterRoutes(engine, Storage.NewStorageManager(&Config.StorageConfig{BasePath: t.TempDir()}), Services.NewLogManagerService(&Config.GetConfig().Log))
+	do := func(user *Models.User, method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		if user != nil {
+			req.Header.Set("Authorization", "Bearer "+factory.Token(user))
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	ruleNames := func(user *Models.User) []string {
+		w := do(user, http.MethodGet, "/api/v1/monitoring/alert-rules")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Data struct {
+				AlertRules []Models.AlertRule `json:"alert_rules"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		names := make([]string, 0, len(resp.Data.AlertRules))
+		for _, rule := range resp.Data.AlertRules {
+			names = append(names, rule.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"队列"}, ruleNames(outsider))
+	assert.Equal(t, []string{"队列", "团队"}, ruleNames(member), "团队成员可见团队规则")
+	assert.Equal(t, []string{"队列", "收入", "团队"}, ruleNames(creator), "创建者可见自己的规则")
+	assert.Equal(t, []string{"队列", "收入", "团队"}, ruleNames(admin))
+
+	// 不可见的规则按不存在处理
+	path := "/api/v1/monitoring/alert-rules/" + strconv.FormatUint(uint64(rules[2].ID), 10)
+	assert.Equal(t, http.StatusNotFound, do(outsider, http.MethodPut, path).Code)
+	assert.Equal(t, http.StatusNotFound, do(outsider, http.MethodDelete, path).Code)
+	assert.Equal(t, http.StatusUnauthorized, do(nil, http.MethodGet, "/api/v1/monitoring/alert-rules").Code)
+}
+
+func TestDashboardWidgetDataHidesSensitiveMetrics(t *testing.T) {
+	newTestVisibility(t)
+	service, core := setupDashboardService(t)
+	dashboard, err := service.CreateDashboard(Services.MonitoringDashboardInput{Name: "经营", IsPublic: true}, dashboardAdmin)
+	require.NoError(t, err)
+	widget, err := service.CreateWidget(dashboard.ID, Services.MonitoringWidgetInput{
+		Name:       "收入",
+		Type:       "stat",
+		DataSource: Services.WidgetDataSourceMetric,
+		Query:      &Services.WidgetQuery{Metrics: []string{"cpu_usage", "revenue_total"}},
+	}, dashboardAdmin)
+	require.NoError(t, err)
+
+	core.Observe("cpu_usage", 30)
+	core.Observe("revenue_total", 1200)
+	data, err := service.WidgetData(dashboard.ID, widget.ID, dashboardOther)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"cpu_usage": 30}, data.Values)
+	data, err = service.WidgetData(dashboard.ID, widget.ID, dashboardAdmin)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"cpu_usage": 30, "revenue_total": 1200}, data.Values)
+}
@@ -0,0 +1,326 @@
+package Notifications
+
+import (
+	"bufio"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupNotificationDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "notifications.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.User{}, &Models.Notification{}))
+	return db
+}
+
+func notificationConfig() *Config.NotificationConfig {
+	return &Config.NotificationConfig{
+		Enabled:               true,
+		PushEnabled:           true,
+		RetentionDays:         30,
+		SecurityAlertLevels:   []string{"high", "critical"},
+		SecurityAlertCooldown: time.Minute,
+	}
+}
+
+func createUser(t *testing.T, db *gorm.DB, username, role string, status int) *Models.User {
+	user := &Models.User{
+		Username: username,
+		Email:    username + "@example.com",
+		Password: "x",
+		Role:     role,
+		Status:   status,
+	}
+	require.NoError(t, db.Create(user).Error)
+	if status != 1 {
+		// status 字段有默认值，零值需要单独更新
+		require.NoError(t, db.Model(user).Update("status", status).Error)
+	}
+	return user
+}
+
+func TestNotificationReadState(t *testing.T) {
+	db := setupNotificationDB(t)
+	service := Services.NewNotificationService(db, notificationConfig())
+	alice := createUser(t, db, "alice", "user", 1)
+	bob := createUser(t, db, "bob", "user", 1)
+
+	first, err := service.Notify(alice.ID, Models.NotificationTypeSystem, "维护通知", "今晚维护", map[string]interface{}{"window": "22:00"})
+	require.NoError(t, err)
+	_, err = service.Notify(alice.ID, Models.NotificationTypeBackup, "备份完成", "", nil)
+	require.NoError(t, err)
+	_, err = service.Notify(bob.ID, Models.NotificationTypeSystem, "维护通知", "今晚维护", nil)
+	require.NoError(t, err)
+
+	unread, err := service.UnreadCount(alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), unread)
+
+	items, total, err := service.List(alice.ID, Services.NotificationFilter{Type: Models.NotificationTypeSystem})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, items, 1)
+	assert.Equal(t, "22:00", items[0].Data["window"])
+
+	// 不能操作其他用户的通知
+	_, err = service.MarkRead(bob.ID, first.ID)
+	assert.ErrorIs(t, err, Services.ErrNotificationNotFound)
+	assert.ErrorIs(t, service.Delete(bob.ID, first.ID), Services.ErrNotificationNotFound)
+
+	read, err := service.MarkRead(alice.ID, first.ID)
+	require.NoError(t, err)
+	assert.True(t, read.IsRead())
+	unread, _ = service.UnreadCount(alice.ID)
+	assert.Equal(t, int64(1), unread)
+
+	_, total, err = service.List(alice.ID, Services.NotificationFilter{UnreadOnly: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	updated, err := service.MarkAllRead(alice.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), updated)
+	unread, _ = service.UnreadCount(alice.ID)
+	assert.Zero(t, unread)
+	unread, _ = service.UnreadCount(bob.ID)
+	assert.Equal(t, int64(1), unread)
+
+	require.NoError(t, service.Delete(alice.ID, first.ID))
+	_, total, _ = service.List(alice.ID, Services.NotificationFilter{})
+	assert.Equal(t, int64(1), total)
+}
+
+func TestNotificationDisabled(t *testing.T) {
+	db := setupNotificationDB(t)
+	config := notificationConfig()
+	config.Enabled = false
+	service := Services.NewNotificationService(db, config)
+	user := createUser(t, db, "alice", "user", 1)
+
+	notification, err := service.Notify(user.ID, Models.NotificationTypeSystem, "维护通知", "", nil)
+	require.NoError(t, err)
+	assert.Nil(t, notification)
+	unread, _ := service.UnreadCount(user.ID)
+	assert.Zero(t, unread)
+}
+
+func TestNotifyAdminsAndSecurityAlertCooldown(t *testing.T) {
+	db := setupNotificationDB(t)
+	service := Services.NewNotificationService(db, notificationConfig())
+	admin := createUser(t, db, "admin", "admin", 1)
+	createUser(t, db, "disabled-admin", "admin", 0)
+	user := createUser(t, db, "alice", "user", 1)
+
+	sent, err := service.NotifyAdmins(Models.NotificationTypeBackup, "备份失败", "磁盘空间不足", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	low := &Models.SecurityEvent{ID: 1, EventType: "login_failed", EventLevel: "low", IPAddress: "10.0.0.1"}
+	assert.False(t, service.NotifySecurityAlert(low))
+
+	high := &Models.SecurityEvent{ID: 2, EventType: "brute_force", EventLevel: "high", IPAddress: "10.0.0.1"}
+	assert.True(t, service.NotifySecurityAlert(high))
+	// 同类型同来源在冷却时间内不重复通知，不同来源单独计算
+	assert.False(t, service.NotifySecurityAlert(high))
+	assert.True(t, service.NotifySecurityAlert(&Models.SecurityEvent{ID: 3, EventType: "brute_force", EventLevel: "critical", IPAddress: "10.0.0.2"}))
+
+	items, total, err := service.List(admin.ID, Services.NotificationFilter{Type: Models.NotificationTypeSecurityAlert})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, "brute_force", items[0].Data["event_type"])
+
+	unread, _ := service.UnreadCount(user.ID)
+	assert.Zero(t, unread)
+}
+
+func TestNotificationSubscribe(t *testing.T) {
+	db := setupNotificationDB(t)
+	service := Services.NewNotificationService(db, notificationConfig())
+	user := createUser(t, db, "alice", "user", 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := service.Subscribe(ctx, user.ID)
+	assert.Equal(t, 1, service.OnlineSubscribers())
+
+	created, err := service.Notify(user.ID, Models.NotificationTypeSystem, "维护通知", "", nil)
+	require.NoError(t, err)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, Services.NotificationEventCreated, event.Type)
+		require.NotNil(t, event.Notification)
+		assert.Equal(t, created.ID, event.Notification.ID)
+		assert.Equal(t, int64(1), event.UnreadCount)
+	case <-time.After(time.Second):
+		t.Fatal("没有收到新通知事件")
+	}
+
+	_, err = service.MarkRead(user.ID, created.ID)
+	require.NoError(t, err)
+	select {
+	case event := <-events:
+		assert.Equal(t, Services.NotificationEventUnreadCount, event.Type)
+		assert.Zero(t, event.UnreadCount)
+	case <-time.After(time.Second):
+		t.Fatal("没有收到未读数事件")
+	}
+
+	cancel()
+	select {
+	case _, ok := <-events:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("取消订阅后通道没有关闭")
+	}
+	assert.Zero(t, service.OnlineSubscribers())
+}
+
+func TestNotificationCleanup(t *testing.T) {
+	db := setupNotificationDB(t)
+	service := Services.NewNotificationService(db, notificationConfig())
+	user := createUser(t, db, "alice", "user", 1)
+
+	old, err := service.Notify(user.ID, Models.NotificationTypeSystem, "旧通知", "", nil)
+	require.NoError(t, err)
+	_, err = service.Notify(user.ID, Models.NotificationTypeSystem, "新通知", "", nil)
+	require.NoError(t, err)
+	require.NoError(t, db.Model(&Models.Notification{}).Where("id = ?", old.ID).
+		UpdateColumn("created_at", time.Now().AddDate(0, 0, -31)).Error)
+
+	deleted, err := service.Cleanup(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	_, total, _ := service.List(user.ID, Services.NotificationFilter{})
+	assert.Equal(t, int64(1), total)
+}
+
+func TestPasswordExpiryInAppNotification(t *testing.T) {
+	db := setupNotificationDB(t)
+	notifications := Services.NewNotificationService(db, notificationConfig())
+	Services.SetDefaultNotificationService(notifications)
+	defer Services.SetDefaultNotificationService(nil)
+
+	securityConfig := &Config.SecurityConfig{}
+	securityConfig.SetDefaults()
+	securityConfig.BaseSecurity.ForcePasswordChange = true
+	securityConfig.BaseSecurity.PasswordChangeInterval = 90 * 24 * time.Hour
+	securityConfig.BaseSecurity.PasswordExpiryWarning = 7 * 24 * time.Hour
+
+	changedAt := time.Now().Add(-85 * 24 * time.Hour)
+	user := &Models.User{Username: "noemail", Password: "x", Status: 1, PasswordChangedAt: &changedAt}
+	require.NoError(t, db.Create(user).Error)
+
+	// 没有邮件服务、用户也没有邮箱时仍然发送站内通知
+	service := Services.NewPasswordExpiryService(db, &securityConfig.BaseSecurity, nil)
+	sent, err := service.NotifyExpiringPasswords()
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	items, total, err := notifications.List(user.ID, Services.NotificationFilter{Type: Models.NotificationTypePasswordExpiring})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Contains(t, items[0].Data, "expires_at")
+
+	// 同一密码周期只提醒一次
+	sent, err = service.NotifyExpiringPasswords()
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+}
+
+func TestNotificationController(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupNotificationDB(t)
+	service := Services.NewNotificationService(db, notificationConfig())
+	user := createUser(t, db, "alice", "user", 1)
+	controller := Controllers.NewNotificationController(service)
+
+	router := gin.New()
+	router.Use(func(ctx *gin.Context) {
+		ctx.Set("user_id", user.ID)
+		ctx.Next()
+	})
+	router.GET("/notifications", controller.GetNotifications)
+	router.GET("/notifications/unread-count", controller.GetUnreadCount)
+	router.POST("/notifications/read-all", controller.MarkAllRead)
+	router.POST("/notifications/:id/read", controller.MarkRead)
+	router.GET("/notifications/stream", controller.Stream)
+
+	created, err := service.Notify(user.ID, Models.NotificationTypeSystem, "维护通知", "", nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/notifications?unread=true", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var listResp struct {
+		Data struct {
+			Notifications []Models.Notification `json:"notifications"`
+			UnreadCount   int64                 `json:"unread_count"`
+			Total         int64                 `json:"total"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listResp))
+	assert.Equal(t, int64(1), listResp.Data.Total)
+	assert.Equal(t, int64(1), listResp.Data.UnreadCount)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/notifications/9999/read", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/notifications/%d/read", created.ID), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/notifications/unread-count", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"unread_count":0`)
+
+	// SSE 连接建立后先推送当前未读数，随后推送新通知
+	server := httptest.NewServer(router)
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/notifications/stream", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent := func() string {
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			if strings.HasPrefix(line, "event:") {
+				return strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+			}
+		}
+	}
+	assert.Equal(t, Services.NotificationEventUnreadCount, readEvent())
+
+	require.Eventually(t, func() bool { return service.OnlineSubscribers() == 1 }, time.Second, 10*time.Millisecond)
+	_, err = service.Notify(user.ID, Models.NotificationTypeBackup, "备份完成", "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, Services.NotificationEventCreated, readEvent())
+}
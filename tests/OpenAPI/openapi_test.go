@@ -0,0 +1,247 @@
+package OpenAPI
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Http/Routes"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type widgetInput struct {
+	Name   string   `json:"name" binding:"required,min=2,max=20"`
+	Kind   string   `json:"kind" binding:"omitempty,oneof=small large"`
+	Size   int      `json:"size" binding:"gte=1,lte=10"`
+	Labels []string `json:"labels" binding:"max=3"`
+	Owner  *owner   `json:"owner"`
+}
+
+type owner struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type widget struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Secret    string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type widgetQuery struct {
+	Verbose bool `form:"verbose"`
+}
+
+var widgetQuerySpec = Utils.QuerySpec{
+	Fields: map[string]Utils.QueryField{
+		"id":   {Type: Utils.QueryInt, Filter: true, Sort: true},
+		"name": {Type: Utils.QueryString, Filter: true},
+	},
+	DefaultSort: "-id",
+}
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// setupWidgetAPI 注册测试接口，validation 为空时不校验请求
+func setupWidgetAPI(validation string) (*gin.Engine, *OpenAPI.Registry) {
+	engine := gin.New()
+	registry := OpenAPI.NewRegistry(OpenAPI.Info{Title: "测试", Version: "1.0.0"})
+	registry.AllowQueryParams("lang")
+	if validation != "" {
+		engine.Use(Middleware.NewOpenAPIValidationMiddleware(registry, validation == "strict").Handle())
+	}
+	engine.GET("/openapi.json", registry.Handler(engine))
+	engine.GET("/plain", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"success": true, "message": "ok"}) }
+	api := registry.Group(engine.Group("/api/widgets"), "组件", OpenAPI.BearerAuth)
+	api.GET("", OpenAPI.Route{Summary: "组件列表", List: &widgetQuerySpec, Query: widgetQuery{}, Response: widget{}}, ok)
+	api.POST("", OpenAPI.Route{Summary: "创建组件", Request: widgetInput{}, Response: widget{}, Status: http.StatusCreated}, ok)
+	api.GET("/:id", OpenAPI.Route{Summary: "组件详情", Params: []OpenAPI.Param{OpenAPI.PathID("id", "组件ID")}, Response: widget{}, Errors: []int{http.StatusNotFound}}, ok)
+	api.GET("/health", OpenAPI.Route{Summary: "公开接口", Public: true}, ok)
+	return engine, registry
+}
+
+func request(engine *gin.Engine, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestDocumentFromTypedRoutes(t *testing.T) {
+	engine, _ := setupWidgetAPI("")
+
+	w := request(engine, http.MethodGet, "/openapi.json", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var doc OpenAPI.Document
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, OpenAPI.Version, doc.OpenAPI)
+	assert.Equal(t, "bearer", doc.Components.SecuritySchemes[OpenAPI.BearerAuth].Scheme)
+
+	// 请求体：binding 标签转换为必填和约束，嵌套结构体放入 components
+	create := doc.Paths["/api/widgets"].Post
+	require.NotNil(t, create)
+	assert.Equal(t, []string{"组件"}, create.Tags)
+	assert.Contains(t, create.Responses, "201")
+	assert.Contains(t, create.Responses, "400")
+	assert.Contains(t, create.Responses, "401")
+	input := doc.Components.Schemas["OpenAPI.widgetInput"]
+	require.NotNil(t, input)
+	assert.Equal(t, []string{"name"}, input.Required)
+	assert.Equal(t, 2, *input.Properties["name"].MinLength)
+	assert.Equal(t, 20, *input.Properties["name"].MaxLength)
+	assert.Equal(t, []interface{}{"small", "large"}, input.Properties["kind"].Enum)
+	assert.Equal(t, float64(10), *input.Properties["size"].Maximum)
+	assert.Equal(t, 3, *input.Properties["labels"].MaxItems)
+	assert.Equal(t, "#/components/schemas/OpenAPI.owner", input.Properties["owner"].AllOf[0].Ref)
+	assert.True(t, input.Properties["owner"].Nullable)
+	assert.Equal(t, "email", doc.Components.Schemas["OpenAPI.owner"].Properties["email"].Format)
+
+	// 响应：json:"-" 的字段不输出，时间为 date-time
+	model := doc.Components.Schemas["OpenAPI.widget"]
+	assert.NotContains(t, model.Properties, "Secret")
+	assert.Equal(t, "date-time", model.Properties["created_at"].Format)
+
+	// 列表接口：分页、筛选、排序参数和列表响应格式
+	list := doc.Paths["/api/widgets"].Get
+	names := map[string]*OpenAPI.Parameter{}
+	for _, param := range list.Parameters {
+		names[param.Name] = param
+	}
+	for _, name := range []string{"page", "limit", "cursor", "sort", "fields", "filter", "verbose"} {
+		assert.Contains(t, names, name)
+	}
+	assert.Equal(t, "deepObject", names["filter"].Style)
+	assert.Equal(t, "-id", names["sort"].Schema.Default)
+	envelope := list.Responses["200"].Content["application/json"].Schema
+	assert.Equal(t, "array", envelope.Properties["data"].Type)
+	assert.Equal(t, "#/components/schemas/Utils.PageMeta", envelope.Properties["meta"].Ref)
+	assert.NotEmpty(t, list.OperationID)
+
+	// 路径参数和认证
+	detail := doc.Paths["/api/widgets/{id}"].Get
+	require.NotNil(t, detail)
+	assert.Equal(t, "integer", detail.Parameters[0].Schema.Type)
+	assert.Contains(t, detail.Responses, "404")
+	assert.NotEmpty(t, detail.Security)
+	assert.Empty(t, doc.Paths["/api/widgets/health"].Get.Security)
+
+	// 未声明的路由同样列出
+	assert.True(t, doc.Paths["/plain"].Get.Undocumented)
+	assert.Equal(t, "#/components/schemas/ErrorResponse", detail.Responses["500"].Content["application/json"].Schema.Ref)
+}
+
+func TestLenientValidation(t *testing.T) {
+	engine, _ := setupWidgetAPI("lenient")
+
+	w := request(engine, http.MethodPost, "/api/widgets", `{"name":"a","kind":"huge","size":11,"owner":{"email":"bad"}}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	var body struct {
+		Success bool     `json:"success"`
+		Errors  []string `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.False(t, body.Success)
+	assert.Len(t, body.Errors, 4, body.Errors)
+
+	w = request(engine, http.MethodPost, "/api/widgets", `{"size":1}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "缺少字段 name")
+
+	// 宽松模式允许未声明的字段和查询参数
+	w = request(engine, http.MethodPost, "/api/widgets", `{"name":"ok","size":1,"extra":true}`)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusOK, request(engine, http.MethodGet, "/api/widgets?page=2&debug=1", "").Code)
+
+	// 路径参数和查询参数类型
+	w = request(engine, http.MethodGet, "/api/widgets/abc", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "路径参数 id")
+	assert.Equal(t, http.StatusBadRequest, request(engine, http.MethodGet, "/api/widgets?limit=500", "").Code)
+	assert.Equal(t, http.StatusBadRequest, request(engine, http.MethodGet, "/api/widgets?verbose=maybe", "").Code)
+
+	// 未声明的路由不校验
+	assert.Equal(t, http.StatusNoContent, request(engine, http.MethodGet, "/plain?anything=1", "").Code)
+}
+
+func TestStrictValidation(t *testing.T) {
+	engine, _ := setupWidgetAPI("strict")
+
+	w := request(engine, http.MethodPost, "/api/widgets", `{"name":"ok","size":1,"extra":true}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "未声明的字段 extra")
+
+	w = request(engine, http.MethodPost, "/api/widgets", `{"name":null,"size":1}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "不能为 null")
+
+	// 可为空的字段允许 null
+	assert.Equal(t, http.StatusOK, request(engine, http.MethodPost, "/api/widgets", `{"name":"ok","size":1,"owner":null}`).Code)
+
+	w = request(engine, http.MethodGet, "/api/widgets?debug=1", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "未声明的查询参数 debug")
+
+	// 筛选参数、全局语言参数不视为未声明
+	w = request(engine, http.MethodGet, "/api/widgets?filter[name][like]=a&sort=-id&lang=en", "")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = request(engine, http.MethodGet, "/api/widgets/1", `{"a":1}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "接口不接受请求体")
+
+	// 处理函数仍可读取请求体
+	engine.POST("/echo-widget", func(c *gin.Context) {
+		var input widgetInput
+		require.NoError(t, c.ShouldBindJSON(&input))
+		c.JSON(http.StatusOK, input)
+	})
+	assert.Contains(t, request(engine, http.MethodPost, "/echo-widget", `{"name":"ok","size":2}`).Body.String(), `"size":2`)
+}
+
+func TestWebhookRoutesAreDocumented(t *testing.T) {
+	// 认证中间件创建时读取配置
+	t.Setenv("JWT_SECRET", "test-jwt-secret-key-for-testing-only-32-chars")
+	Config.LoadConfig()
+
+	registry := OpenAPI.NewRegistry(OpenAPI.Info{Title: "测试", Version: "1.0.0"})
+	OpenAPI.SetDefaultRegistry(registry)
+	t.Cleanup(func() {
+		OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "Cloud Platform API", Version: "1.0.0"}))
+	})
+
+	engine := gin.New()
+	Routes.RegisterWebhookRoutes(engine, nil, Controllers.NewWebhookController(nil))
+	doc := registry.Document(engine.Routes())
+
+	for _, route := range engine.Routes() {
+		item := doc.Paths[strings.NewReplacer(":id", "{id}", ":delivery_id", "{delivery_id}").Replace(route.Path)]
+		require.NotNil(t, item, route.Path)
+	}
+	retry := doc.Paths["/api/v1/admin/webhooks/{id}/deliveries/{delivery_id}/retry"].Post
+	require.NotNil(t, retry)
+	assert.False(t, retry.Undocumented)
+	assert.Contains(t, retry.Responses, "409")
+
+	input := doc.Components.Schemas["Services.WebhookSubscriptionInput"]
+	require.NotNil(t, input)
+	assert.ElementsMatch(t, []string{"name", "url", "event_types"}, input.Required)
+	assert.NotContains(t, doc.Components.Schemas["Models.WebhookSubscription"].Properties, "secret")
+	assert.Contains(t, doc.Components.Schemas["Controllers.WebhookSecretResponse"].Properties, "secret")
+}
@@ -0,0 +1,272 @@
+package OptimisticLock
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Database/Migrations"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Requests"
+	"cloud-platform-api/app/Http/Routes"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"cloud-platform-api/app/Testing"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func setupDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "lock.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.User{}, &Models.AlertRule{}, &Models.AccessControl{}))
+	previous := Database.DB
+	Database.DB = db
+	t.Cleanup(func() { Database.DB = previous })
+	return db
+}
+
+func createRule(t *testing.T, db *gorm.DB) Models.AlertRule {
+	rule := Models.AlertRule{Name: "cpu", Type: "threshold", MetricType: "system", MetricName: "cpu_usage", Condition: ">", Threshold: 80, Severity: "warning", Enabled: true}
+	require.NoError(t, db.Create(&rule).Error)
+	require.Equal(t, uint(1), rule.Version)
+	return rule
+}
+
+type response struct {
+	Success        bool            `json:"success"`
+	Data           json.RawMessage `json:"data"`
+	CurrentVersion uint            `json:"current_version"`
+}
+
+func do(t *testing.T, engine *gin.Engine, method, path, ifMatch, body string, headers ...string) (*httptest.ResponseRecorder, response) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	var resp response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+	return w, resp
+}
+
+func TestSaveWithVersion(t *testing.T) {
+	db := setupDB(t)
+	rule := createRule(t, db)
+
+	// 两个请求读取同一版本，后提交的请求发生冲突
+	first, second := rule, rule
+	first.Threshold = 90
+	require.NoError(t, Models.SaveWithVersion(db, &first, 0))
+	assert.Equal(t, uint(2), first.Version)
+
+	second.Threshold = 70
+	err := Models.SaveWithVersion(db, &second, 0)
+	var conflict *Models.VersionConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.True(t, errors.Is(err, Models.ErrVersionConflict))
+	assert.Equal(t, uint(1), conflict.Expected)
+	assert.Equal(t, uint(2), conflict.Current)
+	assert.Equal(t, uint(1), second.Version)
+
+	var stored Models.AlertRule
+	require.NoError(t, db.First(&stored, rule.ID).Error)
+	assert.Equal(t, 90.0, stored.Threshold)
+	assert.Equal(t, uint(2), stored.Version)
+
+	// 客户端指定的版本优先于加载时的版本
+	stored.Threshold = 95
+	require.ErrorAs(t, Models.SaveWithVersion(db, &stored, 1), &conflict)
+	require.NoError(t, Models.SaveWithVersion(db, &stored, 2))
+	assert.Equal(t, uint(3), stored.Version)
+
+	require.NoError(t, db.Delete(&Models.AlertRule{}, rule.ID).Error)
+	assert.ErrorIs(t, Models.SaveWithVersion(db, &stored, 0), gorm.ErrRecordNotFound)
+}
+
+func TestConcurrentUpdatesOnlyOneWins(t *testing.T) {
+	db := setupDB(t)
+	rule := createRule(t, db)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded, conflicts := 0, 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(threshold float64) {
+			defer wg.Done()
+			copied := rule
+			copied.Threshold = threshold
+			err := Models.SaveWithVersion(db, &copied, 1)
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				succeeded++
+			} else if errors.Is(err, Models.ErrVersionConflict) {
+				conflicts++
+			}
+		}(float64(i))
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, succeeded)
+	assert.Equal(t, 7, conflicts)
+}
+
+// TestUpdateAlertRuleIfMatch 经应用实际注册的路由更新告警规则
+func TestUpdateAlertRuleIfMatch(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-jwt-secret-key-for-testing-only-32-chars")
+	t.Setenv("LOG_BASE_PATH", t.TempDir())
+	Config.LoadConfig()
+	t.Cleanup(func() {
+		OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "Cloud Platform API", Version: "1.0.0"}))
+	})
+	db := setupDB(t)
+	rule := createRule(t, db)
+	factory := Testing.NewFactory(t, db)
+	auth := []string{"Authorization", "Bearer " + factory.Token(factory.User())}
+
+	OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "测试", Version: "1.0.0"}))
+	engine := gin.New()
+	Routes.RegisterRoutes(engine, Storage.NewStorageManager(&Config.StorageConfig{BasePath: t.TempDir()}), Services.NewLogManagerService(&Config.GetConfig().Log))
+	path := "/api/v1/monitoring/alert-rules/" + idString(rule.ID)
+
+	w, resp := do(t, engine, http.MethodPut, path, `"1"`, `{"threshold": 95}`, auth...)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	var data struct {
+		Rule Models.AlertRule `json:"rule"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Data, &data))
+	assert.Equal(t, 95.0, data.Rule.Threshold)
+	assert.Equal(t, "cpu", data.Rule.Name, "未传入的字段保持不变")
+	assert.True(t, data.Rule.Enabled)
+
+	// 过期的 ETag 返回409和当前版本
+	w, resp = do(t, engine, http.MethodPut, path, `"1"`, `{"enabled": false}`, auth...)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, uint(2), resp.CurrentVersion)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+	// 没有 If-Match 时使用请求体中的版本
+	w, _ = do(t, engine, http.MethodPut, path, "", `{"enabled": false, "version": 2}`, auth...)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+
+	w, _ = do(t, engine, http.MethodPut, path, `W/"3"`, `{"enabled": true}`, auth...)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w, _ = do(t, engine, http.MethodPut, path, "abc", `{}`, auth...)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w, _ = do(t, engine, http.MethodPut, "/api/v1/monitoring/alert-rules/999", "", `{}`, auth...)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w, _ = do(t, engine, http.MethodPut, path, "", `{"enabled": false, "version": 4}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestUpdateProfileVersionConflict(t *testing.T) {
+	db := setupDB(t)
+	user := Models.User{Username: "alice", Email: "alice@example.com", Password: "x"}
+	require.NoError(t, db.Create(&user).Error)
+	require.Equal(t, uint(1), user.Version)
+	service := Services.NewAuthServiceWithDB(db)
+
+	updated, err := service.UpdateProfile(idString(user.ID), Requests.UpdateProfileRequest{Avatar: "https://example.com/a.png", Version: 1})
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), updated.Version)
+
+	_, err = service.UpdateProfile(idString(user.ID), Requests.UpdateProfileRequest{Avatar: "https://example.com/b.png", Version: 1})
+	var conflict *Models.VersionConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, uint(2), conflict.Current)
+
+	var stored Models.User
+	require.NoError(t, db.First(&stored, user.ID).Error)
+	assert.Equal(t, "https://example.com/a.png", stored.Avatar)
+}
+
+func TestAccessControlEndpoints(t *testing.T) {
+	db := setupDB(t)
+	controller := Controllers.NewSecurityController()
+	controller.SetSecurityService(Services.NewSecurityService(db, nil))
+	engine := gin.New()
+	engine.GET("/access-controls", controller.GetAccessControls)
+	engine.POST("/access-controls", controller.CreateAccessControl)
+	engine.GET("/access-controls/:id", controller.GetAccessControl)
+	engine.PUT("/access-controls/:id", controller.UpdateAccessControl)
+
+	w, _ := do(t, engine, http.MethodPost, "/access-controls", "", `{"user_id": 1, "resource": "posts", "action": "delete", "permission": "maybe"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w, resp := do(t, engine, http.MethodPost, "/access-controls", "", `{"user_id": 1, "resource": "posts", "action": "delete", "permission": "deny", "active": false}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var control Models.AccessControl
+	require.NoError(t, json.Unmarshal(resp.Data, &control))
+	assert.False(t, control.Active)
+	path := "/access-controls/" + idString(control.ID)
+
+	w, _ = do(t, engine, http.MethodGet, path, "", "")
+	etag := w.Header().Get("ETag")
+	assert.Equal(t, `"1"`, etag)
+
+	w, _ = do(t, engine, http.MethodPut, path, etag, `{"permission": "allow", "active": true}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+	w, resp = do(t, engine, http.MethodPut, path, etag, `{"priority": 5}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, uint(2), resp.CurrentVersion)
+
+	allowed, _ := Services.NewSecurityService(db, nil).CheckAccessControl(context.Background(), 1, "posts", "delete")
+	assert.True(t, allowed)
+}
+
+func TestAddVersionColumnsMigration(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "migrate.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	table := Models.AlertRule{}.TableName()
+	require.NoError(t, db.Exec("CREATE TABLE "+table+" (id integer PRIMARY KEY, name text)").Error)
+	require.NoError(t, db.Exec("INSERT INTO "+table+" (id, name) VALUES (1, 'cpu')").Error)
+
+	migration := &Migrations.AddVersionColumns{}
+	require.NoError(t, migration.Up(db))
+	var version uint
+	require.NoError(t, db.Raw("SELECT version FROM "+table+" WHERE id = 1").Scan(&version).Error)
+	assert.Equal(t, uint(1), version)
+	assert.False(t, db.Migrator().HasTable(&Models.AccessControl{}), "不创建不存在的表")
+
+	require.NoError(t, migration.Down(db))
+	assert.False(t, db.Migrator().HasColumn(&Models.AlertRule{}, "Version"))
+}
+
+func idString(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
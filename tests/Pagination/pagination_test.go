@@ -0,0 +1,224 @@
+package Pagination
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupPaginationDB 创建SQLite数据库并写入25条安全事件，每5条共用同一个创建时间
+func setupPaginationDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "pagination.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&Models.SecurityEvent{},
+		&Models.SecurityResponseExecution{},
+		&Models.AccountLockout{},
+		&Models.ApiKey{},
+		&Models.UserBehaviorProfile{},
+	))
+
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 25; i++ {
+		at := base.Add(time.Duration(i/5) * time.Hour)
+		event := &Models.SecurityEvent{EventType: "login_failed", EventLevel: "medium", IPAddress: "10.0.0.1", CreatedAt: at, UpdatedAt: at}
+		require.NoError(t, db.Create(event).Error)
+	}
+	return db
+}
+
+func eventIDs(events []Models.SecurityEvent) []uint {
+	ids := make([]uint, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func TestPaginateOffsetAndCursor(t *testing.T) {
+	db := setupPaginationDB(t)
+	order := Utils.PageOrder{Column: "created_at"}
+
+	// 游标分页逐页读取，同一时间的记录按ID排序，不重复不遗漏
+	var all []uint
+	req := Utils.PageRequest{Mode: Utils.PageModeCursor, Limit: 7}
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 5)
+		var events []Models.SecurityEvent
+		meta, err := Utils.Paginate(db.Model(&Models.SecurityEvent{}), req, order, &events)
+		require.NoError(t, err)
+		assert.Nil(t, meta.Total, "游标分页默认不统计总数")
+		all = append(all, eventIDs(events)...)
+		if !meta.HasMore {
+			assert.Empty(t, meta.Cursor)
+			break
+		}
+		require.Len(t, events, 7)
+		req.Cursor = meta.Cursor
+	}
+	require.Len(t, all, 25)
+	for i, id := range all {
+		assert.Equal(t, uint(25-i), id)
+	}
+
+	// 页码分页统计总数，最后一页没有更多数据
+	var events []Models.SecurityEvent
+	meta, err := Utils.Paginate(db.Model(&Models.SecurityEvent{}), Utils.PageRequest{Page: 3, Limit: 10, WithTotal: true}, order, &events)
+	require.NoError(t, err)
+	assert.Equal(t, Utils.PageModeOffset, meta.Mode)
+	assert.Equal(t, []uint{5, 4, 3, 2, 1}, eventIDs(events))
+	require.NotNil(t, meta.Total)
+	assert.Equal(t, int64(25), *meta.Total)
+	assert.Equal(t, 3, *meta.TotalPages)
+	assert.False(t, meta.HasMore)
+	assert.Empty(t, meta.Cursor)
+
+	// 升序游标和筛选条件
+	req = Utils.PageRequest{Mode: Utils.PageModeCursor, Limit: 3, WithTotal: true}
+	query := db.Model(&Models.SecurityEvent{}).Where("id > ?", 20)
+	meta, err = Utils.Paginate(query, req, Utils.PageOrder{Column: "created_at", Asc: true}, &events)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{21, 22, 23}, eventIDs(events))
+	assert.Equal(t, int64(5), *meta.Total)
+	req.Cursor = meta.Cursor
+	meta, err = Utils.Paginate(query, req, Utils.PageOrder{Column: "created_at", Asc: true}, &events)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{24, 25}, eventIDs(events))
+	assert.False(t, meta.HasMore)
+
+	// 只按主键排序
+	meta, err = Utils.Paginate(db.Model(&Models.SecurityEvent{}), Utils.PageRequest{Mode: Utils.PageModeCursor, Limit: 2}, Utils.PageOrder{}, &events)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{25, 24}, eventIDs(events))
+	meta, err = Utils.Paginate(db.Model(&Models.SecurityEvent{}), Utils.PageRequest{Mode: Utils.PageModeCursor, Limit: 2, Cursor: meta.Cursor}, Utils.PageOrder{}, &events)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{23, 22}, eventIDs(events))
+
+	_, err = Utils.Paginate(db.Model(&Models.SecurityEvent{}), Utils.PageRequest{Mode: Utils.PageModeCursor, Limit: 2, Cursor: "bm90LWpzb24"}, order, &events)
+	assert.ErrorIs(t, err, Utils.ErrInvalidCursor)
+	_, err = Utils.Paginate(db.Model(&Models.SecurityEvent{}), Utils.PageRequest{Limit: 2}, Utils.PageOrder{Column: "created_at; DROP TABLE users"}, &events)
+	assert.Error(t, err, "未知的排序列")
+}
+
+func TestPageSlice(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	// 按(时间, 键)降序排列
+	items := []struct {
+		at  time.Time
+		key string
+	}{
+		{base.Add(2 * time.Hour), "c"}, {base.Add(time.Hour), "b2"}, {base.Add(time.Hour), "b1"}, {base, "a2"}, {base, "a1"},
+	}
+	key := func(i int) (time.Time, string) { return items[i].at, items[i].key }
+
+	start, end, meta, err := Utils.PageSlice(len(items), Utils.PageRequest{Mode: Utils.PageModeCursor, Limit: 2}, key)
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 2}, []int{start, end})
+	assert.True(t, meta.HasMore)
+
+	start, end, meta, err = Utils.PageSlice(len(items), Utils.PageRequest{Mode: Utils.PageModeCursor, Limit: 2, Cursor: meta.Cursor}, key)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 4}, []int{start, end})
+	start, end, meta, err = Utils.PageSlice(len(items), Utils.PageRequest{Mode: Utils.PageModeCursor, Limit: 2, Cursor: meta.Cursor}, key)
+	require.NoError(t, err)
+	assert.Equal(t, []int{4, 5}, []int{start, end})
+	assert.False(t, meta.HasMore)
+
+	start, end, meta, err = Utils.PageSlice(len(items), Utils.PageRequest{Page: 4, Limit: 2, WithTotal: true}, key)
+	require.NoError(t, err)
+	assert.Equal(t, start, end, "超出范围的页码返回空页")
+	assert.Equal(t, int64(5), *meta.Total)
+
+	_, _, _, err = Utils.PageSlice(len(items), Utils.PageRequest{Mode: Utils.PageModeCursor, Cursor: "%%%"}, key)
+	assert.ErrorIs(t, err, Utils.ErrInvalidCursor)
+}
+
+// listResponse 统一列表响应
+type listResponse struct {
+	Success bool                   `json:"success"`
+	Data    []Models.SecurityEvent `json:"data"`
+	Meta    struct {
+		Mode       string `json:"mode"`
+		PageSize   int    `json:"page_size"`
+		Page       int    `json:"page"`
+		Total      *int64 `json:"total"`
+		TotalPages *int   `json:"total_pages"`
+		Cursor     string `json:"cursor"`
+		HasMore    bool   `json:"has_more"`
+	} `json:"meta"`
+}
+
+func TestSecurityEventsListEnvelope(t *testing.T) {
+	db := setupPaginationDB(t)
+	service := Services.NewSecurityService(db, nil)
+	defer service.Close()
+	controller := Controllers.NewSecurityController()
+	controller.SetSecurityService(service)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/security/events", controller.GetSecurityEvents)
+	get := func(query url.Values) (int, listResponse) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/security/events?"+query.Encode(), nil))
+		var response listResponse
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		}
+		return w.Code, response
+	}
+
+	// 页码分页默认返回总数
+	code, response := get(url.Values{"page": {"2"}, "limit": {"10"}})
+	require.Equal(t, http.StatusOK, code)
+	assert.True(t, response.Success)
+	assert.Len(t, response.Data, 10)
+	assert.Equal(t, uint(15), response.Data[0].ID)
+	assert.Equal(t, Utils.PageModeOffset, response.Meta.Mode)
+	assert.Equal(t, 2, response.Meta.Page)
+	require.NotNil(t, response.Meta.Total)
+	assert.Equal(t, int64(25), *response.Meta.Total)
+	assert.Equal(t, 3, *response.Meta.TotalPages)
+	assert.True(t, response.Meta.HasMore)
+
+	// 游标分页按 meta.cursor 翻页，默认不返回总数
+	seen := 0
+	query := url.Values{"pagination": {"cursor"}, "page_size": {"10"}}
+	for {
+		code, response = get(query)
+		require.Equal(t, http.StatusOK, code)
+		assert.Nil(t, response.Meta.Total)
+		seen += len(response.Data)
+		if !response.Meta.HasMore {
+			break
+		}
+		query = url.Values{"cursor": {response.Meta.Cursor}, "limit": {"10"}}
+	}
+	assert.Equal(t, 25, seen)
+
+	code, response = get(url.Values{"pagination": {"cursor"}, "with_total": {"true"}, "limit": {strconv.Itoa(500)}})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 100, response.Meta.PageSize, "每页数量最大100")
+	assert.Equal(t, int64(25), *response.Meta.Total)
+
+	code, _ = get(url.Values{"cursor": {"invalid!"}})
+	assert.Equal(t, http.StatusBadRequest, code)
+}
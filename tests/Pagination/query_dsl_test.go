@@ -0,0 +1,236 @@
+package Pagination
+
+import (
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// eventSpec 测试用的安全事件查询白名单
+var eventSpec = Utils.QuerySpec{
+	Fields: map[string]Utils.QueryField{
+		"id":          {Column: "id", Type: Utils.QueryInt, Filter: true, Sort: true},
+		"event_type":  {Column: "event_type", Type: Utils.QueryString, Filter: true},
+		"event_level": {Column: "event_level", Type: Utils.QueryString, Filter: true},
+		"user_id":     {Column: "user_id", Type: Utils.QueryInt, Filter: true},
+		"ip_address":  {Column: "ip_address", Type: Utils.QueryString, Filter: true},
+		"risk_score":  {Column: "risk_score", Type: Utils.QueryFloat, Filter: true, Sort: true},
+		"blocked":     {Column: "blocked", Type: Utils.QueryBool, Filter: true},
+		"details":     {Column: "details", Type: Utils.QueryString},
+		"created_at":  {Column: "created_at", Type: Utils.QueryTime, Filter: true, Sort: true},
+	},
+	DefaultSort: "-created_at",
+}
+
+// setupQueryDB 写入不同类型、级别和风险评分的安全事件
+func setupQueryDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "query.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.SecurityEvent{}, &Models.AuditLog{}))
+
+	userID := uint(7)
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	events := []Models.SecurityEvent{
+		{EventType: "sql_injection", EventLevel: "high", IPAddress: "10.0.0.1", RiskScore: 80, Blocked: true, UserID: &userID},
+		{EventType: "xss", EventLevel: "medium", IPAddress: "10.0.0.2", RiskScore: 40},
+		{EventType: "login_failed", EventLevel: "low", IPAddress: "192.168.1.5", RiskScore: 10},
+		{EventType: "sql_injection", EventLevel: "critical", IPAddress: "10.0.0.1", RiskScore: 95, Blocked: true},
+		{EventType: "login_failed", EventLevel: "low", IPAddress: "10_0_0_9", RiskScore: 20, UserID: &userID},
+	}
+	for i := range events {
+		events[i].CreatedAt = base.Add(time.Duration(i) * time.Hour)
+		require.NoError(t, db.Create(&events[i]).Error)
+	}
+	return db
+}
+
+// queryEvents 按查询参数在数据库中查询安全事件
+func queryEvents(t *testing.T, db *gorm.DB, raw string, req Utils.PageRequest) ([]Models.SecurityEvent, Utils.PageMeta, error) {
+	values, err := url.ParseQuery(raw)
+	require.NoError(t, err)
+	q, err := Utils.ParseListQuery(values, eventSpec)
+	if err != nil {
+		return nil, Utils.PageMeta{}, err
+	}
+	query, order, err := q.Apply(db.Model(&Models.SecurityEvent{}), req)
+	if err != nil {
+		return nil, Utils.PageMeta{}, err
+	}
+	var events []Models.SecurityEvent
+	meta, err := Utils.Paginate(query, req, order, &events)
+	return events, meta, err
+}
+
+func TestParseListQueryValidation(t *testing.T) {
+	invalid := []string{
+		"filter[password]=x",                // 不在白名单
+		"filter[details]=x",                 // 不可筛选
+		"filter[risk_score][between]=1",     // 未知操作符
+		"filter[event_type][gt]=a",          // 字符串不支持比较
+		"filter[risk_score][like]=1",        // 只有字符串支持 like
+		"filter[risk_score]=high",           // 类型错误
+		"filter[created_at][gte]=yesterday", // 时间格式错误
+		"filter[blocked][null]=maybe",
+		"filter[event_type]drop=x",
+		"sort=event_type", // 不可排序
+		"sort=-risk_score,id,created_at,-id",
+		"fields=id,password",
+	}
+	for _, raw := range invalid {
+		values, err := url.ParseQuery(raw)
+		require.NoError(t, err)
+		_, err = Utils.ParseListQuery(values, eventSpec)
+		assert.ErrorIs(t, err, Utils.ErrInvalidQuery, raw)
+	}
+
+	values, _ := url.ParseQuery("filter[event_level][in]=high,critical&filter[risk_score][gte]=50&sort=-risk_score,id&fields=id,event_type")
+	q, err := Utils.ParseListQuery(values, eventSpec)
+	require.NoError(t, err)
+	require.Len(t, q.Filters, 2)
+	assert.Equal(t, []interface{}{"high", "critical"}, q.Filters[0].Value)
+	assert.Equal(t, 50.0, q.Filters[1].Value)
+	assert.Equal(t, []Utils.QuerySort{{Field: "risk_score", Desc: true}, {Field: "id"}}, q.Sorts)
+	assert.Equal(t, []string{"id", "event_type"}, q.Fields)
+}
+
+func TestListQueryApply(t *testing.T) {
+	db := setupQueryDB(t)
+	offset := Utils.PageRequest{Page: 1, Limit: 10, WithTotal: true}
+
+	ids := func(raw string) []uint {
+		events, _, err := queryEvents(t, db, raw, offset)
+		require.NoError(t, err, raw)
+		return eventIDs(events)
+	}
+	assert.Equal(t, []uint{5, 4, 3, 2, 1}, ids(""), "默认按创建时间倒序")
+	assert.Equal(t, []uint{4, 1}, ids("filter[event_type]=sql_injection"))
+	assert.Equal(t, []uint{5, 3, 2}, ids("filter[event_type][ne]=sql_injection"))
+	assert.Equal(t, []uint{4, 1}, ids("filter[risk_score][gte]=80"))
+	assert.Equal(t, []uint{5, 3}, ids("filter[risk_score][lt]=40"))
+	assert.Equal(t, []uint{4, 1}, ids("filter[event_level][in]=high,critical"))
+	assert.Equal(t, []uint{5, 1}, ids("filter[user_id][null]=false"))
+	assert.Equal(t, []uint{4, 1}, ids("filter[blocked]=true"))
+	assert.Equal(t, []uint{4, 2, 1}, ids("filter[ip_address][like]=10.0.0"))
+	assert.Equal(t, []uint{5}, ids("filter[ip_address][like]=10_0"), "like 中的通配符按字面匹配")
+	assert.Equal(t, []uint{4, 3, 2}, ids("filter[created_at][gte]=2024-05-01T13:00:00Z&filter[created_at][lt]=2024-05-01T16:00:00Z"))
+	assert.Empty(t, ids("filter[event_type]=xss' OR '1'='1"))
+	assert.Equal(t, []uint{4, 1, 2, 5, 3}, ids("sort=-risk_score"))
+	assert.Equal(t, []uint{1, 2, 3, 4, 5}, ids("sort=created_at,id"))
+
+	// 按非时间字段排序只支持页码分页
+	_, _, err := queryEvents(t, db, "sort=-risk_score", Utils.PageRequest{Mode: Utils.PageModeCursor, Limit: 2})
+	assert.ErrorIs(t, err, Utils.ErrInvalidQuery)
+
+	// 游标分页与筛选组合
+	cursor := Utils.PageRequest{Mode: Utils.PageModeCursor, Limit: 2, WithTotal: true}
+	events, meta, err := queryEvents(t, db, "filter[risk_score][gte]=20&sort=created_at", cursor)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{1, 2}, eventIDs(events))
+	assert.Equal(t, int64(4), *meta.Total)
+	cursor.Cursor = meta.Cursor
+	events, meta, err = queryEvents(t, db, "filter[risk_score][gte]=20&sort=created_at", cursor)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{4, 5}, eventIDs(events))
+	assert.False(t, meta.HasMore)
+
+	// 只返回指定字段，统计总数不受影响
+	values, _ := url.ParseQuery("fields=event_type&filter[blocked]=true")
+	q, err := Utils.ParseListQuery(values, eventSpec)
+	require.NoError(t, err)
+	query, order, err := q.Apply(db.Model(&Models.SecurityEvent{}), offset)
+	require.NoError(t, err)
+	events = nil
+	meta, err = Utils.Paginate(query, offset, order, &events)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), *meta.Total)
+	projected, err := q.Project(events)
+	require.NoError(t, err)
+	rows := projected.([]map[string]interface{})
+	require.Len(t, rows, 2)
+	assert.Equal(t, map[string]interface{}{"event_type": "sql_injection"}, rows[0])
+}
+
+func TestListQueryMatch(t *testing.T) {
+	spec := Utils.QuerySpec{Fields: map[string]Utils.QueryField{
+		"level":      {Type: Utils.QueryString, Filter: true},
+		"value":      {Type: Utils.QueryFloat, Filter: true},
+		"flapping":   {Type: Utils.QueryBool, Filter: true},
+		"created_at": {Type: Utils.QueryTime, Filter: true},
+		"resolved":   {Type: Utils.QueryTime, Filter: true},
+	}}
+	record := map[string]interface{}{
+		"level": "critical", "value": json.Number("92.5"), "flapping": false,
+		"created_at": "2024-05-01T12:00:00Z", "resolved": nil,
+	}
+	match := func(raw string) bool {
+		values, _ := url.ParseQuery(raw)
+		q, err := Utils.ParseListQuery(values, spec)
+		require.NoError(t, err, raw)
+		return q.Match(record)
+	}
+	assert.True(t, match("filter[level]=critical&filter[value][gt]=90"))
+	assert.True(t, match("filter[level][in]=warning,critical&filter[flapping]=false"))
+	assert.True(t, match("filter[level][like]=CRIT&filter[resolved][null]=true"))
+	assert.True(t, match("filter[created_at][gte]=2024-05-01&filter[created_at][lt]=2024-05-02"))
+	assert.False(t, match("filter[value][lte]=90"))
+	assert.False(t, match("filter[level][ne]=critical"))
+	assert.False(t, match("filter[resolved][gte]=2024-01-01"))
+}
+
+func TestAuditLogsEndpoint(t *testing.T) {
+	db := setupQueryDB(t)
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i, action := range []string{"user.login", "user.update", "user.login", "post.delete"} {
+		log := &Models.AuditLog{UserID: uint(i + 1), Username: "admin", Action: action, Level: "info", Status: "success", CreatedAt: base.Add(time.Duration(i) * time.Minute)}
+		require.NoError(t, db.Create(log).Error)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/admin/audit-logs", Controllers.NewAuditLogController(Services.NewAuditService(db)).GetAuditLogs)
+	get := func(query string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/audit-logs?"+query, nil))
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w, body
+	}
+
+	w, body := get(url.Values{"filter[action][like]": {"user."}, "fields": {"id,action"}, "sort": {"-id"}}.Encode())
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	data := body["data"].([]interface{})
+	require.Len(t, data, 3)
+	assert.Equal(t, map[string]interface{}{"id": float64(3), "action": "user.login"}, data[0])
+	assert.Equal(t, float64(3), body["meta"].(map[string]interface{})["total"])
+
+	w, body = get(url.Values{"filter[action]": {"user.login"}, "pagination": {"cursor"}, "limit": {"1"}}.Encode())
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	meta := body["meta"].(map[string]interface{})
+	assert.Equal(t, true, meta["has_more"])
+	assert.Equal(t, float64(3), body["data"].([]interface{})[0].(map[string]interface{})["id"])
+
+	w, _ = get(url.Values{"cursor": {meta["cursor"].(string)}, "filter[action]": {"user.login"}, "limit": {"1"}}.Encode())
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w, _ = get("filter[user_agent]=curl")
+	assert.Equal(t, http.StatusBadRequest, w.Code, "不可筛选的字段")
+	w, _ = get("sort=-username&pagination=cursor")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
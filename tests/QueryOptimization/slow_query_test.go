@@ -0,0 +1,139 @@
+package QueryOptimization
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestNormalizeSQL(t *testing.T) {
+	cases := map[string]struct {
+		input    string
+		expected string
+	}{
+		"字符串和数字": {
+			input:    "SELECT * FROM users WHERE name = 'O''Brien' AND age > 18",
+			expected: "SELECT * FROM users WHERE name = ? AND age > ?",
+		},
+		"PostgreSQL占位符": {
+			input:    `SELECT * FROM "posts" WHERE "posts"."user_id" = $1 LIMIT $2`,
+			expected: `SELECT * FROM "posts" WHERE "posts"."user_id" = ? LIMIT ?`,
+		},
+		"IN列表合并": {
+			input:    "SELECT id FROM tags WHERE id IN (1, 2, 3) AND name IN (?,?)",
+			expected: "SELECT id FROM tags WHERE id IN (?) AND name IN (?)",
+		},
+		"批量插入合并": {
+			input:    "INSERT INTO `tags` (`name`,`slug`) VALUES ('a','a'),('b','b'),('c','c')",
+			expected: "INSERT INTO `tags` (`name`,`slug`) VALUES (?,?)",
+		},
+		"注释和空白": {
+			input:    "SELECT  *\n\tFROM users -- 注释\n WHERE /* 提示 */ id = 7",
+			expected: "SELECT * FROM users WHERE id = ?",
+		},
+		"标识符中的数字保留": {
+			input:    "SELECT col1 FROM table2 WHERE t3.id = 10",
+			expected: "SELECT col1 FROM table2 WHERE t3.id = ?",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Services.NormalizeSQL(tc.input))
+		})
+	}
+}
+
+func setupAnalyzer(t *testing.T, configure func(*Config.QueryOptimizationConfig)) (*gorm.DB, *Services.SlowQueryAnalyzer) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.User{}, &Models.SlowQueryStat{}))
+
+	config := &Config.QueryOptimizationConfig{}
+	config.SetDefaults()
+	// 所有语句都视为慢查询
+	config.SlowQuery.Threshold = time.Nanosecond
+	if configure != nil {
+		configure(config)
+	}
+
+	analyzer := Services.NewSlowQueryAnalyzer(config)
+	require.NoError(t, db.Use(analyzer))
+	return db, analyzer
+}
+
+func TestSlowQueryAnalyzerAggregates(t *testing.T) {
+	db, analyzer := setupAnalyzer(t, nil)
+	ctx := context.Background()
+
+	for _, name := range []string{"alice", "bob", "carol"} {
+		var users []Models.User
+		require.NoError(t, db.Where("username = ?", name).Find(&users).Error)
+	}
+	require.NoError(t, analyzer.Flush(ctx))
+
+	// 再执行两次，统计按增量累加
+	for _, name := range []string{"dave", "erin"} {
+		var users []Models.User
+		require.NoError(t, db.Where("username = ?", name).Find(&users).Error)
+	}
+	require.NoError(t, db.Model(&Models.User{}).Where("id = ?", 1).Update("status", 0).Error)
+	require.NoError(t, analyzer.Flush(ctx))
+
+	stats, err := analyzer.TopSlowQueries(ctx, "call_count", 0)
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+
+	selectStat := stats[0]
+	assert.Equal(t, int64(5), selectStat.CallCount)
+	assert.Equal(t, int64(5), selectStat.SlowCount)
+	assert.Equal(t, "users", selectStat.Table)
+	assert.Contains(t, selectStat.NormalizedSQL, "username = ?")
+	assert.NotContains(t, selectStat.NormalizedSQL, "alice")
+	assert.Greater(t, selectStat.P95Time, 0.0)
+	assert.GreaterOrEqual(t, selectStat.MaxTime, selectStat.P95Time)
+	assert.Contains(t, selectStat.SampleSQL, "username = ")
+
+	t.Run("EXPLAIN执行计划", func(t *testing.T) {
+		plan, err := analyzer.Explain(ctx, selectStat.ID)
+		require.NoError(t, err)
+		assert.NotEmpty(t, plan)
+
+		// 非SELECT语句不执行EXPLAIN
+		_, err = analyzer.Explain(ctx, stats[1].ID)
+		assert.ErrorIs(t, err, Services.ErrExplainUnsupported)
+
+		_, err = analyzer.Explain(ctx, 9999)
+		assert.ErrorIs(t, err, Services.ErrSlowQueryNotFound)
+	})
+}
+
+func TestSlowQueryAnalyzerThresholdAndLimits(t *testing.T) {
+	db, analyzer := setupAnalyzer(t, func(config *Config.QueryOptimizationConfig) {
+		config.SlowQuery.Threshold = time.Hour
+		config.SlowQuery.MaxRecords = 1
+	})
+	ctx := context.Background()
+
+	var users []Models.User
+	require.NoError(t, db.Where("username = ?", "alice").Find(&users).Error)
+	require.NoError(t, db.Where("email = ?", "a@example.com").Find(&users).Error)
+	require.NoError(t, analyzer.Flush(ctx))
+
+	// 未超过阈值的语句不写入统计表
+	stats, err := analyzer.TopSlowQueries(ctx, "", 0)
+	require.NoError(t, err)
+	assert.Empty(t, stats)
+	// 超出 MaxRecords 的新语句不再跟踪
+	assert.Equal(t, int64(1), analyzer.Dropped())
+}
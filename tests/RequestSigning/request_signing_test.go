@@ -0,0 +1,273 @@
+package RequestSigning
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Http/Routes"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"cloud-platform-api/app/Testing"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// setup 创建请求签名服务和使用认证中间件的测试路由，路由返回认证上下文和请求体
+func setup(t *testing.T) (*Services.RequestSigningService, *gorm.DB, *gin.Engine) {
+	t.Setenv("JWT_SECRET", "test-jwt-secret-key-for-testing-only-32-chars")
+	Config.LoadConfig()
+	OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "测试", Version: "1.0.0"}))
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "signing.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.User{}, &Models.SigningClient{}, &Models.SigningNonce{}))
+
+	service := Services.NewRequestSigningService(db, nil)
+	Services.SetDefaultRequestSigningService(service)
+	t.Cleanup(func() {
+		Services.SetDefaultRequestSigningService(nil)
+		OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "Cloud Platform API", Version: "1.0.0"}))
+	})
+
+	engine := gin.New()
+	Routes.RegisterSigningClientRoutes(engine, Storage.NewStorageManager(&Config.StorageConfig{BasePath: t.TempDir()}),
+		Controllers.NewSigningClientController(service))
+	echo := func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.JSON(http.StatusOK, gin.H{
+			"user_id":     c.GetString("user_id"),
+			"username":    c.GetString("username"),
+			"user_role":   c.GetString("user_role"),
+			"auth_method": c.GetString("auth_method"),
+			"body":        string(body),
+		})
+	}
+	group := engine.Group("/api/v1", Middleware.NewAuthMiddleware().Handle())
+	group.GET("/items", echo)
+	group.POST("/items", echo)
+	return service, db, engine
+}
+
+func signedRequest(t *testing.T, method, target, body, keyID, secret string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	require.NoError(t, Services.SignRequest(req, keyID, secret, "host", "content-type"))
+	return req
+}
+
+func serve(engine *gin.Engine, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestSignedRequestAuthenticatesAsClientUser(t *testing.T) {
+	service, db, engine := setup(t)
+	user := Testing.NewFactory(t, db).User()
+	client, err := service.CreateClient(Services.SigningClientInput{Name: "billing-worker", UserID: user.ID}, 1)
+	require.NoError(t, err)
+
+	req := signedRequest(t, http.MethodPost, "/api/v1/items?b=2&a=1&a=0", `{"name":"x"}`, client.KeyID, client.Secret)
+	headers := req.Header.Clone()
+	w := serve(engine, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, fmt.Sprint(user.ID), resp["user_id"])
+	assert.Equal(t, user.Username, resp["username"])
+	assert.Equal(t, "user", resp["user_role"])
+	assert.Equal(t, "signature", resp["auth_method"])
+	assert.Equal(t, `{"name":"x"}`, resp["body"], "处理器可以读取已验证的请求体")
+
+	// 重放同一请求
+	replay := httptest.NewRequest(http.MethodPost, "/api/v1/items?b=2&a=1&a=0", strings.NewReader(`{"name":"x"}`))
+	replay.Header = headers.Clone()
+	w = serve(engine, replay)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "nonce")
+
+	// 查询参数顺序不影响签名
+	req = signedRequest(t, http.MethodGet, "/api/v1/items?b=2&a=1", "", client.KeyID, client.Secret)
+	reordered := httptest.NewRequest(http.MethodGet, "/api/v1/items?a=1&b=2", nil)
+	reordered.Header = req.Header.Clone()
+	assert.Equal(t, http.StatusOK, serve(engine, reordered).Code)
+
+	// 篡改请求体、查询参数和签名的请求头
+	for _, tamper := range []func(headers http.Header) *http.Request{
+		func(h http.Header) *http.Request {
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/items", strings.NewReader(`{"name":"y"}`))
+			r.Header = h
+			return r
+		},
+		func(h http.Header) *http.Request {
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/items?admin=1", strings.NewReader(`{"name":"x"}`))
+			r.Header = h
+			return r
+		},
+		func(h http.Header) *http.Request {
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/items", strings.NewReader(`{"name":"x"}`))
+			r.Header = h
+			r.Header.Set("Content-Type", "text/plain")
+			return r
+		},
+	} {
+		original := signedRequest(t, http.MethodPost, "/api/v1/items", `{"name":"x"}`, client.KeyID, client.Secret)
+		w := serve(engine, tamper(original.Header.Clone()))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "Invalid request signature")
+	}
+
+	// 未知客户端和错误密钥
+	assert.Equal(t, http.StatusUnauthorized, serve(engine, signedRequest(t, http.MethodGet, "/api/v1/items", "", "sk_unknown", client.Secret)).Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(engine, signedRequest(t, http.MethodGet, "/api/v1/items", "", client.KeyID, "wrong")).Code)
+
+	var lastUsed Models.SigningClient
+	require.NoError(t, db.First(&lastUsed, client.ID).Error)
+	assert.NotNil(t, lastUsed.LastUsedAt)
+}
+
+func TestSignedRequestTimestampAndNonce(t *testing.T) {
+	service, db, engine := setup(t)
+	user := Testing.NewFactory(t, db).User()
+	client, err := service.CreateClient(Services.SigningClientInput{Name: "reporter", UserID: user.ID}, 1)
+	require.NoError(t, err)
+
+	now := time.Now()
+	service.SetClock(func() time.Time { return now.Add(6 * time.Minute) })
+	w := serve(engine, signedRequest(t, http.MethodGet, "/api/v1/items", "", client.KeyID, client.Secret))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "timestamp expired")
+
+	service.SetClock(func() time.Time { return now.Add(-6 * time.Minute) })
+	assert.Equal(t, http.StatusUnauthorized, serve(engine, signedRequest(t, http.MethodGet, "/api/v1/items", "", client.KeyID, client.Secret)).Code)
+
+	service.SetClock(time.Now)
+	req := signedRequest(t, http.MethodGet, "/api/v1/items", "", client.KeyID, client.Secret)
+	req.Header.Set(Services.SigningHeaderNonce, "short")
+	assert.Equal(t, http.StatusUnauthorized, serve(engine, req).Code)
+
+	// 随机数在时间戳过期后清理
+	require.Equal(t, http.StatusOK, serve(engine, signedRequest(t, http.MethodGet, "/api/v1/items", "", client.KeyID, client.Secret)).Code)
+	purged, err := service.PurgeNonces(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), purged)
+	service.SetClock(func() time.Time { return now.Add(10 * time.Minute) })
+	purged, err = service.PurgeNonces(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+}
+
+func TestSigningSecretRotationAndDisable(t *testing.T) {
+	service, db, engine := setup(t)
+	factory := Testing.NewFactory(t, db)
+	user := factory.User()
+	client, err := service.CreateClient(Services.SigningClientInput{Name: "sync", UserID: user.ID}, 1)
+	require.NoError(t, err)
+	oldSecret := client.Secret
+
+	grace := time.Hour
+	rotated, err := service.RotateSecret(client.ID, &grace)
+	require.NoError(t, err)
+	assert.NotEqual(t, oldSecret, rotated.Secret)
+	require.NotNil(t, rotated.PreviousSecretExpiresAt)
+
+	// 宽限期内新旧密钥都有效
+	assert.Equal(t, http.StatusOK, serve(engine, signedRequest(t, http.MethodGet, "/api/v1/items", "", client.KeyID, oldSecret)).Code)
+	assert.Equal(t, http.StatusOK, serve(engine, signedRequest(t, http.MethodGet, "/api/v1/items", "", client.KeyID, rotated.Secret)).Code)
+
+	// 宽限期结束后旧密钥失效
+	require.NoError(t, db.Model(&Models.SigningClient{}).Where("id = ?", client.ID).Update("previous_secret_expires_at", time.Now().Add(-time.Second)).Error)
+	assert.Equal(t, http.StatusUnauthorized, serve(engine, signedRequest(t, http.MethodGet, "/api/v1/items", "", client.KeyID, oldSecret)).Code)
+	assert.Equal(t, http.StatusOK, serve(engine, signedRequest(t, http.MethodGet, "/api/v1/items", "", client.KeyID, rotated.Secret)).Code)
+
+	// 宽限期为0时旧密钥立即失效
+	zero := time.Duration(0)
+	again, err := service.RotateSecret(client.ID, &zero)
+	require.NoError(t, err)
+	assert.Nil(t, again.PreviousSecretExpiresAt)
+	assert.Equal(t, http.StatusUnauthorized, serve(engine, signedRequest(t, http.MethodGet, "/api/v1/items", "", client.KeyID, rotated.Secret)).Code)
+	assert.Equal(t, http.StatusOK, serve(engine, signedRequest(t, http.MethodGet, "/api/v1/items", "", client.KeyID, again.Secret)).Code)
+
+	// 停用客户端或禁用用户后拒绝
+	_, err = service.UpdateClient(client.ID, Services.SigningClientInput{Name: "sync", Status: Models.SigningClientDisabled})
+	require.NoError(t, err)
+	w := serve(engine, signedRequest(t, http.MethodGet, "/api/v1/items", "", client.KeyID, again.Secret))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "disabled")
+
+	_, err = service.UpdateClient(client.ID, Services.SigningClientInput{Name: "sync", Status: Models.SigningClientActive})
+	require.NoError(t, err)
+	require.NoError(t, db.Model(user).Update("status", 0).Error)
+	assert.Equal(t, http.StatusUnauthorized, serve(engine, signedRequest(t, http.MethodGet, "/api/v1/items", "", client.KeyID, again.Secret)).Code)
+}
+
+func TestSigningClientAdminAPI(t *testing.T) {
+	_, db, engine := setup(t)
+	factory := Testing.NewFactory(t, db)
+	admin := factory.Admin()
+	user := factory.User()
+	request := func(token, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		return serve(engine, req)
+	}
+	adminToken := factory.Token(admin)
+
+	assert.Equal(t, http.StatusForbidden, request(factory.Token(user), http.MethodGet, "/api/v1/admin/signing-clients", "").Code)
+	assert.Equal(t, http.StatusBadRequest, request(adminToken, http.MethodPost, "/api/v1/admin/signing-clients", `{"name":"x","user_id":9999}`).Code)
+
+	w := request(adminToken, http.MethodPost, "/api/v1/admin/signing-clients", fmt.Sprintf(`{"name":"etl","user_id":%d}`, user.ID))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Data Controllers.SigningClientSecretResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.True(t, strings.HasPrefix(created.Data.KeyID, "sk_"))
+	assert.True(t, strings.HasPrefix(created.Data.Secret, "sks_"))
+
+	w = request(adminToken, http.MethodGet, "/api/v1/admin/signing-clients", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), created.Data.Secret, "列表不返回密钥")
+
+	path := fmt.Sprintf("/api/v1/admin/signing-clients/%d/rotate-secret", created.Data.Client.ID)
+	assert.Equal(t, http.StatusBadRequest, request(adminToken, http.MethodPost, path, `{"grace_period":"soon"}`).Code)
+	w = request(adminToken, http.MethodPost, path, `{"grace_period":"30m"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var rotated struct {
+		Data Controllers.SigningClientSecretResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rotated))
+	assert.NotEqual(t, created.Data.Secret, rotated.Data.Secret)
+	require.NotNil(t, rotated.Data.Client.PreviousSecretExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(30*time.Minute), *rotated.Data.Client.PreviousSecretExpiresAt, time.Minute)
+
+	// 签名客户端对应的用户不是管理员，不能管理签名客户端
+	signed := signedRequest(t, http.MethodGet, "/api/v1/admin/signing-clients", "", created.Data.KeyID, rotated.Data.Secret)
+	assert.Equal(t, http.StatusForbidden, serve(engine, signed).Code)
+
+	path = fmt.Sprintf("/api/v1/admin/signing-clients/%d", created.Data.Client.ID)
+	assert.Equal(t, http.StatusOK, request(adminToken, http.MethodDelete, path, "").Code)
+	assert.Equal(t, http.StatusNotFound, request(adminToken, http.MethodGet, path, "").Code)
+}
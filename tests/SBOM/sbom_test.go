@@ -0,0 +1,272 @@
+package SBOM
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Routes"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/SBOM"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"cloud-platform-api/app/Testing"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func setupDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "sbom.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.User{}, &Models.SecurityEvent{}, &Models.DependencyVulnerability{}))
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestDetectLicense(t *testing.T) {
+	cases := map[string]string{
+		"Apache License\n  Version 2.0, January 2004":                                                      "Apache-2.0",
+		"MIT License\n\nPermission is hereby granted, free of charge, to any person":                       "MIT",
+		"Redistribution and use in source and binary forms ... * Neither the name of Google Inc.":          "BSD-3-Clause",
+		"Redistribution and use in source and binary forms, with or without modification, are permitted":   "BSD-2-Clause",
+		"Mozilla Public License Version 2.0":                                                               "MPL-2.0",
+		"Permission to use, copy, modify, and/or distribute this software for any purpose with or without": "ISC",
+		"GNU GENERAL PUBLIC LICENSE\n Version 3, 29 June 2007":                                             "GPL-3.0",
+		"All rights reserved.": SBOM.UnknownLicense,
+	}
+	for text, expected := range cases {
+		assert.Equal(t, expected, SBOM.DetectLicense(text), text)
+	}
+
+	dir := t.TempDir()
+	assert.Equal(t, SBOM.UnknownLicense, SBOM.DetectModuleLicense(dir))
+	assert.Equal(t, SBOM.UnknownLicense, SBOM.DetectModuleLicense(""))
+}
+
+func TestParseMergesBuildInfoWithEmbeddedLicenses(t *testing.T) {
+	embedded := []byte(`{"name": "cloud-platform-api", "go_version": "go1.23.0", "generated_at": "2024-12-20T00:00:00Z", "components": [
+		{"path": "github.com/gin-gonic/gin", "version": "v1.9.0", "license": "MIT"},
+		{"path": "example.com/fork", "version": "v1.0.1", "license": "Apache-2.0"},
+		{"path": "example.com/removed", "version": "v0.1.0", "license": "BSD-3-Clause"}
+	]}`)
+	info := &debug.BuildInfo{
+		GoVersion: "go1.23.4",
+		Main:      debug.Module{Path: "cloud-platform-api", Version: "v1.2.0"},
+		Deps: []*debug.Module{
+			{Path: "github.com/gin-gonic/gin", Version: "v1.9.1"},
+			{Path: "example.com/original", Version: "v1.0.0", Replace: &debug.Module{Path: "example.com/fork", Version: "v1.0.1"}},
+			{Path: "example.com/new", Version: "v0.2.0"},
+		},
+	}
+
+	doc, err := SBOM.Parse(embedded, info)
+	require.NoError(t, err)
+	assert.Equal(t, "build_info", doc.Source)
+	assert.Equal(t, "go1.23.4", doc.GoVersion)
+	assert.Equal(t, "v1.2.0", doc.Version)
+	require.NotNil(t, doc.GeneratedAt)
+	assert.Equal(t, []SBOM.Component{
+		{Path: "example.com/fork", Version: "v1.0.1", License: "Apache-2.0", Replace: "example.com/original"},
+		{Path: "example.com/new", Version: "v0.2.0", License: SBOM.UnknownLicense},
+		{Path: "github.com/gin-gonic/gin", Version: "v1.9.1", License: "MIT"},
+	}, doc.Components)
+	assert.Equal(t, map[string]int{"Apache-2.0": 1, "MIT": 1, SBOM.UnknownLicense: 1}, doc.Licenses)
+
+	// 没有构建信息时使用内嵌清单
+	doc, err = SBOM.Parse(embedded, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "embedded", doc.Source)
+	assert.Len(t, doc.Components, 3)
+
+	bom := doc.CycloneDX()
+	assert.Equal(t, "CycloneDX", bom["bomFormat"])
+	components := bom["components"].([]map[string]interface{})
+	assert.Equal(t, "pkg:golang/example.com/fork@v1.0.1", components[0]["purl"])
+
+	_, err = SBOM.Parse([]byte("not json"), nil)
+	assert.Error(t, err)
+}
+
+// osvServer 模拟OSV漏洞库，vulns 为模块路径到漏洞编号的映射
+type osvServer struct {
+	mutex sync.Mutex
+	vulns map[string][]string
+}
+
+func (s *osvServer) set(vulns map[string][]string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.vulns = vulns
+}
+
+func (s *osvServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if r.URL.Path == "/v1/querybatch" {
+		var body struct {
+			Queries []struct {
+				Package struct {
+					Name string `json:"name"`
+				} `json:"package"`
+			} `json:"queries"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		var results []string
+		for _, q := range body.Queries {
+			var ids []string
+			for _, id := range s.vulns[q.Package.Name] {
+				ids = append(ids, fmt.Sprintf(`{"id": %q}`, id))
+			}
+			results = append(results, `{"vulns": [`+strings.Join(ids, ",")+`]}`)
+		}
+		w.Write([]byte(`{"results": [` + strings.Join(results, ",") + `]}`))
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/v1/vulns/")
+	fmt.Fprintf(w, `{"id": %q, "summary": "summary of %s", "database_specific": {"severity": "CRITICAL"}}`, id, id)
+}
+
+func newDependencyService(t *testing.T, db *gorm.DB, server *httptest.Server) *Services.DependencyVulnerabilityService {
+	service := Services.NewDependencyVulnerabilityService(db, nil, nil)
+	service.SetScanner(Services.NewOSVScanner(server.URL), []Services.OSVModule{
+		{Path: "example.com/web", Version: "v1.2.3"},
+		{Path: "example.com/db", Version: "v0.4.0"},
+	})
+	return service
+}
+
+func TestDependencyVulnerabilityCheckRaisesEventsForNewVulnerabilities(t *testing.T) {
+	db := setupDB(t)
+	osv := &osvServer{vulns: map[string][]string{"example.com/web": {"GO-2024-0001", "GO-2024-0002"}}}
+	server := httptest.NewServer(osv)
+	defer server.Close()
+	service := newDependencyService(t, db, server)
+
+	result, err := service.Check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.CheckedModules)
+	assert.Len(t, result.New, 2)
+	assert.Equal(t, 2, result.Open)
+
+	var events []Models.SecurityEvent
+	require.NoError(t, db.Order("id").Find(&events).Error)
+	require.Len(t, events, 2)
+	assert.Equal(t, Services.DependencyVulnerabilityEventType, events[0].EventType)
+	assert.Equal(t, "critical", events[0].EventLevel)
+	assert.Equal(t, "example.com/web@v1.2.3", events[0].Resource)
+	assert.Contains(t, []string{"GO-2024-0001", "GO-2024-0002"}, events[0].Action)
+
+	// 已知漏洞不重复记录事件
+	result, err = service.Check(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, result.New)
+
+	// 漏洞库新公开的漏洞和已修复的漏洞
+	osv.set(map[string][]string{"example.com/web": {"GO-2024-0002"}, "example.com/db": {"GO-2024-0003"}})
+	result, err = service.Check(context.Background())
+	require.NoError(t, err)
+	require.Len(t, result.New, 1)
+	assert.Equal(t, "GO-2024-0003", result.New[0].VulnID)
+	assert.Equal(t, "example.com/db", result.New[0].Module)
+	assert.Equal(t, "summary of GO-2024-0003", result.New[0].Summary)
+	assert.Equal(t, 1, result.Resolved)
+
+	var count int64
+	require.NoError(t, db.Model(&Models.SecurityEvent{}).Count(&count).Error)
+	assert.EqualValues(t, 3, count)
+
+	open, err := service.List(false)
+	require.NoError(t, err)
+	assert.Len(t, open, 2)
+	all, err := service.List(true)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+
+	// 已解决的漏洞再次出现时重新记录
+	osv.set(map[string][]string{"example.com/web": {"GO-2024-0001", "GO-2024-0002"}, "example.com/db": {"GO-2024-0003"}})
+	result, err = service.Check(context.Background())
+	require.NoError(t, err)
+	require.Len(t, result.New, 1)
+	assert.Equal(t, "GO-2024-0001", result.New[0].VulnID)
+	assert.Nil(t, result.New[0].ResolvedAt)
+}
+
+func TestSBOMAPI(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-jwt-secret-key-for-testing-only-32-chars")
+	Config.LoadConfig()
+	OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "测试", Version: "1.0.0"}))
+	t.Cleanup(func() {
+		OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "Cloud Platform API", Version: "1.0.0"}))
+	})
+
+	db := setupDB(t)
+	factory := Testing.NewFactory(t, db)
+	adminToken := factory.Token(factory.Admin())
+	userToken := factory.Token(factory.User())
+	storageManager := Storage.NewStorageManager(&Config.StorageConfig{BasePath: t.TempDir()})
+
+	newEngine := func(controller *Controllers.SBOMController) func(token, method, path string) *httptest.ResponseRecorder {
+		engine := gin.New()
+		Routes.RegisterSBOMRoutes(engine, storageManager, controller)
+		return func(token, method, path string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			return w
+		}
+	}
+
+	request := newEngine(Controllers.NewSBOMController(nil))
+	assert.Equal(t, http.StatusForbidden, request(userToken, http.MethodGet, "/api/v1/system/sbom").Code)
+
+	w := request(adminToken, http.MethodGet, "/api/v1/system/sbom")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		Data SBOM.Document `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.NotEmpty(t, response.Data.Components)
+	paths := make([]string, 0, len(response.Data.Components))
+	for _, component := range response.Data.Components {
+		paths = append(paths, component.Path)
+	}
+	assert.Contains(t, paths, "github.com/gin-gonic/gin")
+
+	w = request(adminToken, http.MethodGet, "/api/v1/system/sbom?format=cyclonedx")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"bomFormat":"CycloneDX"`)
+	assert.Equal(t, http.StatusBadRequest, request(adminToken, http.MethodGet, "/api/v1/system/sbom?format=spdx").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, request(adminToken, http.MethodGet, "/api/v1/system/sbom/vulnerabilities").Code)
+
+	server := httptest.NewServer(&osvServer{vulns: map[string][]string{"example.com/db": {"GO-2024-0003"}}})
+	defer server.Close()
+	request = newEngine(Controllers.NewSBOMController(newDependencyService(t, db, server)))
+	w = request(adminToken, http.MethodPost, "/api/v1/system/sbom/vulnerabilities/check")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = request(adminToken, http.MethodGet, "/api/v1/system/sbom/vulnerabilities")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "GO-2024-0003")
+}
@@ -0,0 +1,473 @@
+package SMS
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Testing"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeDriver 记录发送的短信，按顺序返回预设的错误
+type fakeDriver struct {
+	mu   sync.Mutex
+	errs []error
+	sent []*Services.SMS
+}
+
+func (d *fakeDriver) Name() string {
+	return Config.SMSDriverLog
+}
+
+func (d *fakeDriver) Send(ctx context.Context, message *Services.SMS) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.errs) > 0 {
+		err := d.errs[0]
+		d.errs = d.errs[1:]
+		return "", err
+	}
+	d.sent = append(d.sent, message)
+	return "fake-" + message.OutID, nil
+}
+
+func (d *fakeDriver) last() *Services.SMS {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.sent) == 0 {
+		return nil
+	}
+	return d.sent[len(d.sent)-1]
+}
+
+func setupSMSDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "sms.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.User{}, &Models.SMSTemplate{}, &Models.SMSMessage{}))
+	return db
+}
+
+func smsConfig(driver string) *Config.SMSConfig {
+	return &Config.SMSConfig{
+		Driver:          driver,
+		DefaultCountry:  "86",
+		Senders:         "86:云平台,1:+15550001111",
+		SignName:        "默认签名",
+		From:            "+15559999999",
+		MaxRetries:      1,
+		WebhookSecret:   "secret",
+		CodeLength:      6,
+		CodeTTL:         time.Minute,
+		CodeInterval:    time.Minute,
+		CodeMaxAttempts: 3,
+	}
+}
+
+func newFakeService(t *testing.T) (*Services.SMSService, *fakeDriver, *gorm.DB) {
+	db := setupSMSDB(t)
+	service := Services.NewSMSService(db, smsConfig(Config.SMSDriverLog))
+	driver := &fakeDriver{}
+	service.SetDriver(driver)
+	return service, driver, db
+}
+
+func TestNormalizePhoneAndSenders(t *testing.T) {
+	service, driver, _ := newFakeService(t)
+
+	phone, country, err := service.NormalizePhone("138 0013 8000")
+	require.NoError(t, err)
+	assert.Equal(t, "+8613800138000", phone)
+	assert.Equal(t, "86", country)
+
+	phone, country, err = service.NormalizePhone("+1 (415) 555-0100")
+	require.NoError(t, err)
+	assert.Equal(t, "+14155550100", phone)
+	assert.Equal(t, "1", country)
+
+	_, _, err = service.NormalizePhone("abc")
+	assert.ErrorIs(t, err, Services.ErrInvalidPhoneNumber)
+
+	// 按国家区号选择发送方，未配置的区号使用默认签名
+	_, err = service.Send(context.Background(), "+14155550100", Services.SMSTemplateMonitoringAlert, map[string]string{"severity": "critical", "title": "CPU", "message": "过高"})
+	require.NoError(t, err)
+	assert.Equal(t, "+15550001111", driver.last().Sender)
+	assert.Equal(t, "【critical】CPU：过高", driver.last().Content)
+
+	_, err = service.Send(context.Background(), "+447700900123", Services.SMSTemplateMonitoringAlert, map[string]string{"severity": "warning", "title": "磁盘", "message": "不足"})
+	require.NoError(t, err)
+	assert.Equal(t, "默认签名", driver.last().Sender)
+}
+
+func TestSMSTemplates(t *testing.T) {
+	service, driver, _ := newFakeService(t)
+
+	_, err := Services.RenderSMSTemplate("您好{{name}}，订单{{ order }}已发货", map[string]string{"name": "张三"})
+	assert.ErrorIs(t, err, Services.ErrSMSTemplateVariable)
+
+	require.NoError(t, service.SaveTemplate(&Models.SMSTemplate{Name: "shipped", Content: "您好{{name}}，订单{{ order }}已发货", ProviderCode: "SMS_001", Enabled: true}))
+	record, err := service.Send(context.Background(), "13800138000", "shipped", map[string]string{"name": "张三", "order": "A1"})
+	require.NoError(t, err)
+	assert.Equal(t, Models.SMSStatusSent, record.Status)
+	assert.Equal(t, "您好张三，订单A1已发货", driver.last().Content)
+	assert.Equal(t, "SMS_001", driver.last().TemplateCode)
+	assert.Equal(t, "A1", driver.last().Params["order"])
+
+	// 覆盖内置模板，删除后恢复默认内容
+	require.NoError(t, service.SaveTemplate(&Models.SMSTemplate{Name: Services.SMSTemplateMonitoringAlert, Content: "告警:{{title}}", Enabled: false}))
+	_, err = service.Send(context.Background(), "13800138000", Services.SMSTemplateMonitoringAlert, map[string]string{"title": "CPU"})
+	assert.ErrorIs(t, err, Services.ErrSMSTemplateDisabled)
+
+	templates, err := service.ListTemplates()
+	require.NoError(t, err)
+	names := make(map[string]bool)
+	for _, template := range templates {
+		names[template.Name] = true
+	}
+	assert.True(t, names["shipped"])
+	assert.True(t, names[Services.SMSTemplateVerificationCode])
+
+	require.NoError(t, service.DeleteTemplate(Services.SMSTemplateMonitoringAlert))
+	template, err := service.GetTemplate(Services.SMSTemplateMonitoringAlert)
+	require.NoError(t, err)
+	assert.True(t, template.Enabled)
+	assert.ErrorIs(t, service.DeleteTemplate("missing"), Services.ErrSMSTemplateNotFound)
+	_, err = service.GetTemplate("missing")
+	assert.ErrorIs(t, err, Services.ErrSMSTemplateNotFound)
+}
+
+func TestSMSRetryAndPermanentFailure(t *testing.T) {
+	service, driver, _ := newFakeService(t)
+	vars := map[string]string{"severity": "critical", "title": "CPU", "message": "过高"}
+
+	driver.errs = []error{errors.New("connection reset")}
+	record, err := service.Send(context.Background(), "13800138000", Services.SMSTemplateMonitoringAlert, vars)
+	require.NoError(t, err)
+	assert.Equal(t, 2, record.Attempts)
+
+	driver.errs = []error{&Services.SMSDeliveryError{Permanent: true, Code: "isv.MOBILE_NUMBER_ILLEGAL", Err: errors.New("号码无效")}}
+	record, err = service.Send(context.Background(), "13800138000", Services.SMSTemplateMonitoringAlert, vars)
+	require.Error(t, err)
+	assert.Equal(t, Models.SMSStatusFailed, record.Status)
+	assert.Equal(t, 1, record.Attempts)
+	assert.Equal(t, "isv.MOBILE_NUMBER_ILLEGAL", record.ErrorCode)
+
+	messages, total, err := service.ListMessages(Services.SMSMessageFilter{Status: Models.SMSStatusFailed})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "+8613800138000", messages[0].Phone)
+}
+
+func TestAliyunDriver(t *testing.T) {
+	var form url.Values
+	var responses = []string{
+		`{"Code":"isp.SYSTEM_ERROR","Message":"系统错误"}`,
+		`{"Code":"OK","Message":"OK","BizId":"biz-1","RequestId":"req"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		form = r.PostForm
+		w.Write([]byte(responses[0]))
+		responses = responses[1:]
+	}))
+	defer server.Close()
+
+	config := smsConfig(Config.SMSDriverAliyun)
+	config.AccessKeyID = "key"
+	config.AccessKeySecret = "secret"
+	config.Region = "cn-hangzhou"
+	config.Endpoint = server.URL
+	db := setupSMSDB(t)
+	service := Services.NewSMSService(db, config)
+
+	// 阿里云必须使用模板CODE
+	_, err := service.Send(context.Background(), "13800138000", Services.SMSTemplateMonitoringAlert, map[string]string{"severity": "critical", "title": "CPU", "message": "过高"})
+	require.Error(t, err)
+	assert.True(t, Services.IsPermanentSMSError(err))
+
+	require.NoError(t, service.SaveTemplate(&Models.SMSTemplate{Name: Services.SMSTemplateVerificationCode, Content: "验证码{{code}}，{{minutes}}分钟有效", ProviderCode: "SMS_123", Enabled: true}))
+	require.NoError(t, service.SendVerificationCode(context.Background(), "13800138000", "login"))
+
+	assert.Equal(t, "SendSms", form.Get("Action"))
+	assert.Equal(t, "13800138000", form.Get("PhoneNumbers"))
+	assert.Equal(t, "云平台", form.Get("SignName"))
+	assert.Equal(t, "SMS_123", form.Get("TemplateCode"))
+	assert.Equal(t, "key", form.Get("AccessKeyId"))
+	assert.Equal(t, "HMAC-SHA1", form.Get("SignatureMethod"))
+	assert.NotEmpty(t, form.Get("Signature"))
+	var params map[string]string
+	require.NoError(t, json.Unmarshal([]byte(form.Get("TemplateParam")), &params))
+	assert.Regexp(t, `^\d{6}$`, params["code"])
+	assert.Equal(t, "1", params["minutes"])
+
+	// 投递记录不保存明文验证码
+	var record Models.SMSMessage
+	require.NoError(t, db.Where("template = ?", Services.SMSTemplateVerificationCode).First(&record).Error)
+	assert.Equal(t, "biz-1", record.ProviderMessageID)
+	assert.Equal(t, 2, record.Attempts)
+	assert.Contains(t, record.Content, "******")
+	assert.NotContains(t, record.Content, params["code"])
+	assert.Equal(t, form.Get("OutId"), jsonID(record.ID))
+
+	require.NoError(t, service.VerifyCode("+86 138 0013 8000", "login", params["code"]))
+}
+
+func jsonID(id uint) string {
+	data, _ := json.Marshal(id)
+	return string(data)
+}
+
+func TestTwilioDriver(t *testing.T) {
+	var form url.Values
+	var user, pass string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2010-04-01/Accounts/AC123/Messages.json", r.URL.Path)
+		user, pass, _ = r.BasicAuth()
+		require.NoError(t, r.ParseForm())
+		form = r.PostForm
+		if form.Get("To") == "+15005550001" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":21211,"message":"Invalid 'To' Phone Number","status":400}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"sid":"SM123","status":"queued"}`))
+	}))
+	defer server.Close()
+
+	config := smsConfig(Config.SMSDriverTwilio)
+	config.AccountSID = "AC123"
+	config.AuthToken = "token"
+	config.StatusCallbackURL = "https://api.example.com/api/v1/sms/callbacks/twilio?token=secret"
+	config.Endpoint = server.URL
+	service := Services.NewSMSService(setupSMSDB(t), config)
+
+	record, err := service.Send(context.Background(), "+14155550100", Services.SMSTemplateMonitoringAlert, map[string]string{"severity": "critical", "title": "CPU", "message": "过高"})
+	require.NoError(t, err)
+	assert.Equal(t, "SM123", record.ProviderMessageID)
+	assert.Equal(t, "AC123", user)
+	assert.Equal(t, "token", pass)
+	assert.Equal(t, "+15550001111", form.Get("From"))
+	assert.Equal(t, "【critical】CPU：过高", form.Get("Body"))
+	assert.Equal(t, config.StatusCallbackURL, form.Get("StatusCallback"))
+
+	record, err = service.Send(context.Background(), "+15005550001", Services.SMSTemplateMonitoringAlert, map[string]string{"severity": "critical", "title": "CPU", "message": "过高"})
+	require.Error(t, err)
+	assert.True(t, Services.IsPermanentSMSError(err))
+	assert.Equal(t, "21211", record.ErrorCode)
+	assert.Equal(t, 1, record.Attempts)
+}
+
+func TestDeliveryReceipts(t *testing.T) {
+	service, _, db := newFakeService(t)
+	vars := map[string]string{"severity": "critical", "title": "CPU", "message": "过高"}
+	first, err := service.Send(context.Background(), "13800138000", Services.SMSTemplateMonitoringAlert, vars)
+	require.NoError(t, err)
+	second, err := service.Send(context.Background(), "13800138001", Services.SMSTemplateMonitoringAlert, vars)
+	require.NoError(t, err)
+	// 回执按驱动名称匹配，模拟服务商发送的记录
+	require.NoError(t, db.Model(&Models.SMSMessage{}).Where("id IN ?", []uint{first.ID, second.ID}).Update("driver", Config.SMSDriverAliyun).Error)
+
+	body := `[{"phone_number":"13800138000","success":true,"biz_id":"` + first.ProviderMessageID + `"},
+		{"phone_number":"13800138001","success":false,"err_code":"MK:0001","err_msg":"用户关机","out_id":"` + jsonID(second.ID) + `"}]`
+	updated, err := service.HandleDeliveryReceipt(Config.SMSDriverAliyun, []byte(body))
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated)
+
+	var reloaded Models.SMSMessage
+	require.NoError(t, db.First(&reloaded, first.ID).Error)
+	assert.Equal(t, Models.SMSStatusDelivered, reloaded.Status)
+	assert.NotNil(t, reloaded.DeliveredAt)
+	var undelivered Models.SMSMessage
+	require.NoError(t, db.First(&undelivered, second.ID).Error)
+	assert.Equal(t, Models.SMSStatusUndelivered, undelivered.Status)
+	assert.Equal(t, "MK:0001", undelivered.ErrorCode)
+
+	require.NoError(t, db.Model(&Models.SMSMessage{}).Where("id = ?", first.ID).
+		Updates(map[string]interface{}{"driver": Config.SMSDriverTwilio, "provider_message_id": "SM1", "status": Models.SMSStatusSent}).Error)
+	// 中间状态不更新记录
+	updated, err = service.HandleDeliveryReceipt(Config.SMSDriverTwilio, []byte("MessageSid=SM1&MessageStatus=sent"))
+	require.NoError(t, err)
+	assert.Zero(t, updated)
+	updated, err = service.HandleDeliveryReceipt(Config.SMSDriverTwilio, []byte("MessageSid=SM1&MessageStatus=undelivered&ErrorCode=30003"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, updated)
+	var twilio Models.SMSMessage
+	require.NoError(t, db.First(&twilio, first.ID).Error)
+	assert.Equal(t, Models.SMSStatusUndelivered, twilio.Status)
+	assert.Equal(t, "30003", twilio.ErrorCode)
+
+	_, err = service.HandleDeliveryReceipt("unknown", nil)
+	assert.ErrorIs(t, err, Services.ErrUnsupportedSMSProvider)
+}
+
+var codePattern = regexp.MustCompile(`\d{6}`)
+
+func TestVerificationCodes(t *testing.T) {
+	service, driver, _ := newFakeService(t)
+	ctx := context.Background()
+
+	require.NoError(t, service.SendVerificationCode(ctx, "13800138000", "login"))
+	assert.ErrorIs(t, service.SendVerificationCode(ctx, "+8613800138000", "login"), Services.ErrSMSCodeTooFrequent)
+	code := codePattern.FindString(driver.last().Content)
+	require.NotEmpty(t, code)
+
+	// 不同场景的验证码互不影响
+	assert.ErrorIs(t, service.VerifyCode("13800138000", "reset", code), Services.ErrSMSCodeInvalid)
+	assert.ErrorIs(t, service.VerifyCode("13800138000", "login", "000000x"), Services.ErrSMSCodeInvalid)
+	require.NoError(t, service.VerifyCode("13800138000", "login", code))
+	// 验证成功后失效
+	assert.ErrorIs(t, service.VerifyCode("13800138000", "login", code), Services.ErrSMSCodeInvalid)
+
+	require.NoError(t, service.SendVerificationCode(ctx, "13800138001", "login"))
+	code = codePattern.FindString(driver.last().Content)
+	assert.ErrorIs(t, service.VerifyCode("13800138001", "login", "bad"), Services.ErrSMSCodeInvalid)
+	assert.ErrorIs(t, service.VerifyCode("13800138001", "login", "bad"), Services.ErrSMSCodeInvalid)
+	assert.ErrorIs(t, service.VerifyCode("13800138001", "login", "bad"), Services.ErrSMSCodeTooManyAttempts)
+	assert.ErrorIs(t, service.VerifyCode("13800138001", "login", code), Services.ErrSMSCodeInvalid)
+
+	// 发送失败时可以立即重新获取
+	driver.errs = []error{&Services.SMSDeliveryError{Permanent: true, Err: errors.New("拒绝")}}
+	require.Error(t, service.SendVerificationCode(ctx, "13800138002", "login"))
+	require.NoError(t, service.SendVerificationCode(ctx, "13800138002", "login"))
+}
+
+func TestSMSNotificationChannel(t *testing.T) {
+	service, driver, _ := newFakeService(t)
+	monitoring := &Config.MonitoringConfig{}
+	monitoring.NotificationConfig.SMS.Enabled = true
+	monitoring.NotificationConfig.SMS.PhoneNumbers = "13800138000, +14155550100"
+
+	channel := Services.NewSMSNotificationChannel(monitoring, service)
+	assert.True(t, channel.IsEnabled())
+	assert.Equal(t, "sms", channel.GetName())
+
+	err := channel.SendAlert(Services.MonitoringAlert{Severity: "critical", Title: "数据库熔断", Message: strings.Repeat("长", 300), Timestamp: time.Now()})
+	require.NoError(t, err)
+	require.Len(t, driver.sent, 2)
+	assert.Equal(t, "+14155550100", driver.sent[1].To)
+	assert.Less(t, len([]rune(driver.sent[0].Content)), 230)
+
+	channel.SetEnabled(false)
+	assert.Error(t, channel.SendAlert(Services.MonitoringAlert{}))
+}
+
+func TestSMSMFAController(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service, driver, db := newFakeService(t)
+	user := &Models.User{Username: "alice", Email: "alice@example.com", Password: "x", Phone: "13800138000"}
+	require.NoError(t, db.Create(user).Error)
+	noPhone := &Models.User{Username: "bob", Email: "bob@example.com", Password: "x"}
+	require.NoError(t, db.Create(noPhone).Error)
+
+	tokens := Services.NewTokenBlacklistService(nil)
+	require.NoError(t, tokens.RequireMFAReverification(user.ID, time.Hour))
+	defer tokens.ClearMFAReverification(user.ID)
+
+	controller := Controllers.NewSMSController(service, "secret")
+	controller.SetTokenBlacklistService(tokens)
+	currentUser := user.ID
+	router := gin.New()
+	router.Use(func(ctx *gin.Context) {
+		ctx.Set("user_id", currentUser)
+		ctx.Next()
+	})
+	router.POST("/mfa/send", controller.SendMFACode)
+	router.POST("/mfa/verify", controller.VerifyMFACode)
+	router.POST("/callbacks/:provider", controller.HandleCallback)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/mfa/send", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "+86138****8000")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/mfa/send", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/mfa/verify", strings.NewReader(`{"code":"bad"}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.True(t, tokens.IsMFAReverificationRequired(user.ID))
+
+	code := codePattern.FindString(driver.last().Content)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/mfa/verify", strings.NewReader(`{"code":"`+code+`"}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, tokens.IsMFAReverificationRequired(user.ID))
+
+	currentUser = noPhone.ID
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/mfa/send", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/callbacks/twilio?token=wrong", strings.NewReader("MessageSid=SM1&MessageStatus=delivered")))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/callbacks/twilio?token=secret", strings.NewReader("MessageSid=SM1&MessageStatus=delivered")))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":0`)
+}
+
+func TestAuthMiddlewareEnforcesMFAReverification(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, _, db := newFakeService(t)
+	t.Setenv("JWT_SECRET", "test-jwt-secret-key-for-testing-only-32-chars")
+	Config.LoadConfig()
+	factory := Testing.NewFactory(t, db)
+	user := factory.User()
+	token := factory.Token(user)
+
+	tokens := Services.NewTokenBlacklistService(nil)
+	require.NoError(t, tokens.RequireMFAReverification(user.ID, time.Hour))
+	defer tokens.ClearMFAReverification(user.ID)
+
+	engine := gin.New()
+	engine.Use(Middleware.NewAuthMiddleware().Handle())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	engine.GET("/api/v1/posts", ok)
+	engine.POST("/api/v1/auth/mfa/sms/verify", ok)
+	engine.POST("/api/v1/auth/logout", ok)
+	request := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	// 完成MFA验证前只能访问MFA验证和登出接口
+	w := request(http.MethodGet, "/api/v1/posts")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "MFA_REVERIFICATION_REQUIRED")
+	assert.Equal(t, "true", w.Header().Get("X-MFA-Reverification-Required"))
+	assert.Equal(t, http.StatusOK, request(http.MethodPost, "/api/v1/auth/mfa/sms/verify").Code)
+	assert.Equal(t, http.StatusOK, request(http.MethodPost, "/api/v1/auth/logout").Code)
+
+	require.NoError(t, tokens.ClearMFAReverification(user.ID))
+	w = request(http.MethodGet, "/api/v1/posts")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("X-MFA-Reverification-Required"))
+}
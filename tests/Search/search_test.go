@@ -0,0 +1,252 @@
+package Search
+
+import (
+	"bufio"
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/I18n"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupSearchDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.User{}, &Models.Post{}))
+
+	users := []Models.User{
+		{Username: "alice", Email: "alice@example.com", Password: "x", Role: "admin", Status: 1},
+		{Username: "bob", Email: "bob@example.com", Password: "x", Role: "user", Status: 1},
+	}
+	require.NoError(t, db.Create(&users).Error)
+
+	posts := []Models.Post{
+		{Title: "Go search engine", Content: "Building a search engine in Go", Status: 1, UserID: users[0].ID},
+		{Title: "Draft about search", Content: "Not published yet", Status: 0, UserID: users[1].ID},
+		{Title: "Cooking", Content: "Nothing related, but search appears once", Status: 1, UserID: users[1].ID},
+	}
+	require.NoError(t, db.Create(&posts).Error)
+	// status 默认值为1，草稿需要单独更新
+	require.NoError(t, db.Model(&posts[1]).Update("status", 0).Error)
+	return db
+}
+
+func TestDatabaseSearchDriver(t *testing.T) {
+	db := setupSearchDB(t)
+	service := Services.NewSearchServiceWithDriver(&Config.SearchConfig{Driver: "database", DefaultPageSize: 10, MaxPageSize: 50}, nil, db)
+	ctx := context.Background()
+
+	t.Run("按相关度排序并高亮", func(t *testing.T) {
+		result, err := service.Search(ctx, Services.SearchQuery{Query: "search", Indices: []string{"posts"}, Highlight: true}, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, "database", result.Driver)
+		assert.Equal(t, int64(3), result.Total)
+		require.Len(t, result.Hits, 3)
+		assert.Equal(t, "Go search engine", result.Hits[0].Title)
+		assert.Contains(t, result.Hits[0].Highlights["title"][0], "<em>search</em>")
+	})
+
+	t.Run("过滤和分页", func(t *testing.T) {
+		result, err := service.Search(ctx, Services.SearchQuery{
+			Query:    "search",
+			Indices:  []string{"posts"},
+			Filters:  map[string]string{"status": "1"},
+			Page:     2,
+			PageSize: 1,
+		}, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(2), result.Total)
+		require.Len(t, result.Hits, 1)
+		assert.Equal(t, "Cooking", result.Hits[0].Title)
+		assert.Empty(t, result.Hits[0].Highlights)
+	})
+
+	t.Run("多个查询词为AND", func(t *testing.T) {
+		result, err := service.Search(ctx, Services.SearchQuery{Query: "search go", Indices: []string{"posts"}}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), result.Total)
+	})
+
+	t.Run("用户结果不包含密码", func(t *testing.T) {
+		result, err := service.Search(ctx, Services.SearchQuery{Query: "alice", Indices: []string{"users"}}, nil)
+		require.NoError(t, err)
+		require.Len(t, result.Hits, 1)
+		assert.NotContains(t, result.Hits[0].Source, "password")
+	})
+
+	t.Run("权限和参数校验", func(t *testing.T) {
+		_, err := service.Search(ctx, Services.SearchQuery{Query: "alice", Indices: []string{"users"}}, []string{"posts"})
+		var i18nErr *I18n.Error
+		require.True(t, errors.As(err, &i18nErr))
+		assert.Equal(t, "search.index_forbidden", i18nErr.Key)
+
+		_, err = service.Search(ctx, Services.SearchQuery{Query: "x", Filters: map[string]string{"password": "x"}}, nil)
+		require.True(t, errors.As(err, &i18nErr))
+		assert.Equal(t, "search.filter_unsupported", i18nErr.Key)
+
+		_, err = service.Search(ctx, Services.SearchQuery{Query: "  "}, nil)
+		require.True(t, errors.As(err, &i18nErr))
+		assert.Equal(t, "search.query_required", i18nErr.Key)
+	})
+
+	t.Run("LIKE通配符被转义", func(t *testing.T) {
+		result, err := service.Search(ctx, Services.SearchQuery{Query: "%", Indices: []string{"posts"}}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), result.Total)
+	})
+}
+
+func TestHighlightText(t *testing.T) {
+	assert.Equal(t, "a <em>Search</em> &lt;b&gt;", Services.HighlightText("a Search <b>", []string{"search"}, 50))
+	assert.Equal(t, "", Services.HighlightText("nothing", []string{"search"}, 50))
+
+	long := strings.Repeat("x", 100) + " needle " + strings.Repeat("y", 100)
+	fragment := Services.HighlightText(long, []string{"needle"}, 30)
+	assert.True(t, strings.HasPrefix(fragment, "…"))
+	assert.True(t, strings.HasSuffix(fragment, "…"))
+	assert.Contains(t, fragment, "<em>needle</em>")
+}
+
+// fakeElasticsearch 记录请求并模拟别名、批量写入和搜索接口
+type fakeElasticsearch struct {
+	mu       sync.Mutex
+	aliases  map[string][]string
+	indexed  map[string]int
+	requests []string
+}
+
+func (f *fakeElasticsearch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	body, _ := io.ReadAll(r.Body)
+
+	switch {
+	case r.Method == http.MethodHead:
+		if _, ok := f.aliases[strings.TrimPrefix(r.URL.Path, "/")]; ok {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == http.MethodPut:
+		var payload map[string]interface{}
+		json.Unmarshal(body, &payload)
+		if aliases, ok := payload["aliases"].(map[string]interface{}); ok {
+			for alias := range aliases {
+				f.aliases[alias] = []string{strings.TrimPrefix(r.URL.Path, "/")}
+			}
+		}
+		w.Write([]byte(`{"acknowledged":true}`))
+	case strings.HasPrefix(r.URL.Path, "/_alias/"):
+		alias := strings.TrimPrefix(r.URL.Path, "/_alias/")
+		backings, ok := f.aliases[alias]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{}`))
+			return
+		}
+		response := map[string]interface{}{}
+		for _, backing := range backings {
+			response[backing] = map[string]interface{}{"aliases": map[string]interface{}{alias: map[string]interface{}{}}}
+		}
+		json.NewEncoder(w).Encode(response)
+	case r.URL.Path == "/_aliases":
+		var payload struct {
+			Actions []map[string]map[string]string `json:"actions"`
+		}
+		json.Unmarshal(body, &payload)
+		for _, action := range payload.Actions {
+			if add, ok := action["add"]; ok {
+				f.aliases[add["alias"]] = []string{add["index"]}
+			}
+		}
+		w.Write([]byte(`{"acknowledged":true}`))
+	case r.URL.Path == "/_bulk":
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		for scanner.Scan() {
+			var action map[string]map[string]string
+			json.Unmarshal(scanner.Bytes(), &action)
+			if index, ok := action["index"]; ok {
+				f.indexed[index["_index"]]++
+				scanner.Scan()
+			}
+		}
+		w.Write([]byte(`{"errors":false,"items":[]}`))
+	case strings.HasSuffix(r.URL.Path, "/_search"):
+		w.Write([]byte(`{
+			"hits": {"total": {"value": 1}, "hits": [
+				{"_id": "7", "_score": 1.5, "_source": {"search_index": "posts", "id": 7, "title": "Hello"}, "highlight": {"title": ["<em>Hello</em>"]}}
+			]},
+			"aggregations": {"by_index": {"buckets": [{"key": "posts", "doc_count": 1}]}}
+		}`))
+	default:
+		w.Write([]byte(`{"acknowledged":true}`))
+	}
+}
+
+func TestElasticsearchSearchDriver(t *testing.T) {
+	fake := &fakeElasticsearch{aliases: map[string][]string{}, indexed: map[string]int{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	db := setupSearchDB(t)
+	config := &Config.SearchConfig{
+		Driver:        "elasticsearch",
+		SyncBatchSize: 2,
+		Elasticsearch: Config.ElasticsearchSearchConfig{
+			Addresses:   []string{server.URL},
+			IndexPrefix: "test",
+		},
+	}
+	service := Services.NewSearchServiceWithDriver(config, nil, db)
+	ctx := context.Background()
+
+	t.Run("启动时创建索引别名", func(t *testing.T) {
+		require.NoError(t, service.Start())
+		defer service.Stop()
+		assert.Contains(t, fake.aliases, "test-posts")
+		assert.Contains(t, fake.aliases, "test-users")
+	})
+
+	t.Run("重建索引后切换别名", func(t *testing.T) {
+		previous := fake.aliases["test-posts"][0]
+		count, err := service.Reindex(ctx, "posts")
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), count)
+
+		current := fake.aliases["test-posts"][0]
+		assert.NotEqual(t, previous, current)
+		assert.Equal(t, 3, fake.indexed[current])
+		assert.Contains(t, fake.requests, "DELETE /"+previous)
+	})
+
+	t.Run("解析搜索结果", func(t *testing.T) {
+		result, err := service.Search(ctx, Services.SearchQuery{Query: "hello", Indices: []string{"posts"}, Highlight: true}, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, "elasticsearch", result.Driver)
+		assert.Equal(t, int64(1), result.Total)
+		assert.Equal(t, int64(1), result.Totals["posts"])
+		require.Len(t, result.Hits, 1)
+		assert.Equal(t, "posts", result.Hits[0].Index)
+		assert.Equal(t, "Hello", result.Hits[0].Title)
+		assert.NotContains(t, result.Hits[0].Source, "search_index")
+	})
+}
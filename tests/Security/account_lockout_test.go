@@ -0,0 +1,169 @@
+package Security
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type recordingMailer struct {
+	to, subject, body string
+}
+
+func (m *recordingMailer) SendNotificationEmail(to, subject, body string) error {
+	m.to, m.subject, m.body = to, subject, body
+	return nil
+}
+
+func unlockConfig() *Config.AccountUnlockConfig {
+	config := &Config.SecurityConfig{}
+	config.SetDefaults()
+	config.AccountUnlock.Secret = "unlock-secret"
+	config.AccountUnlock.BaseURL = "https://example.com/"
+	return &config.AccountUnlock
+}
+
+func createLockout(t *testing.T, db *gorm.DB, username, ip string, lockedAt time.Time) *Models.AccountLockout {
+	lockout := &Models.AccountLockout{
+		Username:    username,
+		IPAddress:   ip,
+		LockoutType: "login_attempts",
+		Reason:      "登录尝试次数过多",
+		LockoutTime: lockedAt,
+		ExpiryTime:  lockedAt.Add(time.Hour),
+		Active:      true,
+	}
+	require.NoError(t, db.Create(lockout).Error)
+	return lockout
+}
+
+func TestAccountLockoutListAndUnlock(t *testing.T) {
+	db := setupResponseDB(t)
+	service := Services.NewAccountLockoutService(db, unlockConfig(), nil)
+
+	now := time.Now()
+	first := createLockout(t, db, "alice", "198.51.100.1", now.Add(-time.Minute))
+	createLockout(t, db, "alice", "198.51.100.2", now)
+	createLockout(t, db, "bob", "198.51.100.1", now)
+	expired := createLockout(t, db, "carol", "198.51.100.3", now.Add(-2*time.Hour))
+
+	lockouts, total, err := service.List(Services.AccountLockoutFilter{Username: "ali", ActiveOnly: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, "198.51.100.2", lockouts[0].IPAddress)
+
+	_, total, _ = service.List(Services.AccountLockoutFilter{IPAddress: "198.51.100.1"})
+	assert.Equal(t, int64(2), total)
+	_, total, _ = service.List(Services.AccountLockoutFilter{ActiveOnly: true})
+	assert.Equal(t, int64(3), total)
+
+	unlocked, err := service.Unlock(first.ID, 1, "客服确认")
+	require.NoError(t, err)
+	assert.False(t, unlocked.Active)
+	assert.Equal(t, uint(1), *unlocked.UnlockedBy)
+	_, err = service.Unlock(first.ID, 1, "")
+	assert.ErrorIs(t, err, Services.ErrLockoutNotActive)
+	_, err = service.Unlock(expired.ID, 1, "")
+	assert.ErrorIs(t, err, Services.ErrLockoutNotActive)
+
+	count, err := service.UnlockBy("", "198.51.100.1", 1, "误封")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	_, err = service.UnlockBy("", "", 1, "")
+	assert.Error(t, err)
+
+	_, total, _ = service.List(Services.AccountLockoutFilter{ActiveOnly: true})
+	assert.Equal(t, int64(1), total)
+}
+
+func TestAccountLockoutSelfServiceUnlock(t *testing.T) {
+	db := setupResponseDB(t)
+	require.NoError(t, db.AutoMigrate(&Models.User{}))
+	user := Models.User{Username: "alice", Email: "alice@example.com", Password: "x"}
+	require.NoError(t, db.Create(&user).Error)
+
+	mailer := &recordingMailer{}
+	config := unlockConfig()
+	service := Services.NewAccountLockoutService(db, config, mailer)
+
+	lockout := createLockout(t, db, "alice", "198.51.100.1", time.Now())
+	require.NoError(t, service.NotifyLockout(lockout))
+	assert.Equal(t, "alice@example.com", mailer.to)
+	assert.Contains(t, mailer.body, "https://example.com/api/v1/security/unlock?token=")
+
+	link := service.UnlockURL(lockout)
+	parsed, err := url.Parse(link)
+	require.NoError(t, err)
+	token := parsed.Query().Get("token")
+
+	// 篡改的令牌和其他密钥签发的令牌无效
+	_, err = service.SelfServiceUnlock(token + "0")
+	assert.ErrorIs(t, err, Services.ErrInvalidUnlockToken)
+	other := *config
+	other.Secret = "other"
+	_, err = Services.NewAccountLockoutService(db, &other, nil).SelfServiceUnlock(token)
+	assert.ErrorIs(t, err, Services.ErrInvalidUnlockToken)
+
+	unlocked, err := service.SelfServiceUnlock(token)
+	require.NoError(t, err)
+	assert.Equal(t, Services.SelfServiceUnlockReason, unlocked.UnlockReason)
+
+	// 令牌只能使用一次
+	_, err = service.SelfServiceUnlock(token)
+	assert.ErrorIs(t, err, Services.ErrLockoutNotActive)
+
+	// 过期的令牌
+	config.LinkTTL = -time.Second
+	lockout = createLockout(t, db, "alice", "198.51.100.1", time.Now())
+	_, err = service.SelfServiceUnlock(service.GenerateUnlockToken(lockout))
+	assert.ErrorIs(t, err, Services.ErrUnlockTokenExpired)
+
+	// 未知用户不发送邮件
+	mailer.to = ""
+	require.NoError(t, service.NotifyLockout(&Models.AccountLockout{Username: "nobody"}))
+	assert.Empty(t, mailer.to)
+}
+
+func TestAccountLockoutStatisticsInReport(t *testing.T) {
+	db := setupResponseDB(t)
+	require.NoError(t, db.AutoMigrate(&Models.LoginAttempt{}, &Models.SecurityReport{}))
+	service := Services.NewSecurityService(db, nil)
+	defer service.Close()
+	lockouts := service.GetAccountLockoutService()
+
+	now := time.Now()
+	first := createLockout(t, db, "alice", "198.51.100.1", now)
+	createLockout(t, db, "alice", "198.51.100.1", now)
+	second := createLockout(t, db, "bob", "198.51.100.2", now)
+	_, err := lockouts.Unlock(first.ID, 1, "管理员解锁")
+	require.NoError(t, err)
+	_, err = lockouts.Unlock(second.ID, 0, Services.SelfServiceUnlockReason)
+	require.NoError(t, err)
+
+	stats, err := lockouts.Statistics(context.Background(), now.Add(-time.Hour), now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), stats["total_lockouts"])
+	assert.Equal(t, int64(1), stats["active_lockouts"])
+	assert.Equal(t, int64(1), stats["manual_unlocks"])
+	assert.Equal(t, int64(1), stats["self_service_unlocks"])
+	assert.Equal(t, map[string]int64{"login_attempts": 3}, stats["by_type"])
+	top := stats["top_usernames"].([]map[string]interface{})
+	require.NotEmpty(t, top)
+	assert.Equal(t, "alice", top[0]["username"])
+
+	report, err := service.GenerateSecurityReport(context.Background(), "account_lockouts", "daily", now.Add(-time.Hour), now.Add(time.Hour), 1)
+	require.NoError(t, err)
+	var content map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(report.Content), &content))
+	assert.Equal(t, float64(3), content["total_lockouts"])
+	assert.Contains(t, report.Summary, "账户锁定次数: 3（生效中 1）")
+}
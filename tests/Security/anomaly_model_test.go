@@ -0,0 +1,142 @@
+package Security
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Services"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func anomalyConfig() *Config.AnomalyDetectionConfig {
+	config := &Config.AnomalyDetectionConfig{
+		MachineLearningEnabled: true,
+		MLModelType:            Services.StatisticalAnomalyModelName,
+		LearningMode:           true,
+		LearningPeriod:         30 * time.Minute,
+	}
+	return config
+}
+
+// observeSteady 每分钟记录 perMinute 次请求，返回结束时间
+func observeSteady(model Services.AnomalyModel, userID uint, start time.Time, minutes, perMinute int) time.Time {
+	for i := 0; i < minutes; i++ {
+		minute := start.Add(time.Duration(i) * time.Minute)
+		for j := 0; j < perMinute; j++ {
+			model.Observe(Services.AnomalyObservation{UserID: userID, EventType: "api_access", Time: minute.Add(time.Duration(j) * time.Second)})
+		}
+	}
+	return start.Add(time.Duration(minutes) * time.Minute)
+}
+
+func TestStatisticalAnomalyModelBurst(t *testing.T) {
+	model, err := Services.NewAnomalyModel(anomalyConfig())
+	require.NoError(t, err)
+	assert.Equal(t, Services.StatisticalAnomalyModelName, model.Name())
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	// 学习期内只学习不评分
+	now := observeSteady(model, 1, start, 20, 2)
+	_, ready := model.Score(Services.AnomalyObservation{UserID: 1, Time: now})
+	assert.False(t, ready)
+
+	// 未学习过的用户不评分
+	_, ready = model.Score(Services.AnomalyObservation{UserID: 2, Time: now})
+	assert.False(t, ready)
+
+	now = observeSteady(model, 1, now, 20, 2)
+	normal := Services.AnomalyObservation{UserID: 1, Time: now}
+	model.Observe(normal)
+	score, ready := model.Score(normal)
+	require.True(t, ready)
+	assert.Less(t, score, 0.2)
+
+	// 同一分钟内请求数远超基线
+	var burst Services.AnomalyObservation
+	for i := 0; i < 30; i++ {
+		burst = Services.AnomalyObservation{UserID: 1, Time: now.Add(time.Duration(i) * time.Second)}
+		model.Observe(burst)
+	}
+	score, ready = model.Score(burst)
+	require.True(t, ready)
+	assert.Greater(t, score, 0.9)
+}
+
+func TestStatisticalAnomalyModelRareHour(t *testing.T) {
+	config := anomalyConfig()
+	config.LearningMode = false
+	model := Services.NewStatisticalAnomalyModel(config)
+
+	// 每天10点活跃，持续一周
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	for day := 0; day < 7; day++ {
+		observeSteady(model, 1, start.AddDate(0, 0, day), 30, 1)
+	}
+
+	daytime := Services.AnomalyObservation{UserID: 1, Time: start.AddDate(0, 0, 7).Add(15 * time.Minute)}
+	model.Observe(daytime)
+	score, ready := model.Score(daytime)
+	require.True(t, ready)
+	assert.Less(t, score, 0.5)
+
+	night := Services.AnomalyObservation{UserID: 1, Time: start.AddDate(0, 0, 7).Add(-7 * time.Hour)}
+	model.Observe(night)
+	score, ready = model.Score(night)
+	require.True(t, ready)
+	assert.Greater(t, score, 0.6)
+}
+
+func TestStatisticalAnomalyModelSaveLoad(t *testing.T) {
+	config := anomalyConfig()
+	config.LearningMode = false
+	model := Services.NewStatisticalAnomalyModel(config)
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	now := observeSteady(model, 7, start, 15, 3)
+	observeSteady(model, 3, start, 15, 1)
+
+	var buf bytes.Buffer
+	require.NoError(t, model.Save(&buf))
+
+	restored := Services.NewStatisticalAnomalyModel(config)
+	require.NoError(t, restored.Load(bytes.NewReader(buf.Bytes())))
+	assert.Equal(t, []uint{3, 7}, restored.Users())
+
+	observation := Services.AnomalyObservation{UserID: 7, Time: now}
+	want, ready := model.Score(observation)
+	require.True(t, ready)
+	got, ready := restored.Score(observation)
+	require.True(t, ready)
+	assert.InDelta(t, want, got, 1e-9)
+
+	assert.Error(t, restored.Load(bytes.NewReader([]byte(`{"model":"other"}`))))
+	assert.Error(t, restored.Load(bytes.NewReader([]byte("not json"))))
+}
+
+type constantAnomalyModel struct{}
+
+func (constantAnomalyModel) Name() string                                      { return "constant" }
+func (constantAnomalyModel) Observe(Services.AnomalyObservation)               {}
+func (constantAnomalyModel) Save(io.Writer) error                              { return nil }
+func (constantAnomalyModel) Load(io.Reader) error                              { return nil }
+func (constantAnomalyModel) Score(Services.AnomalyObservation) (float64, bool) { return 1, true }
+
+func TestAnomalyModelRegistry(t *testing.T) {
+	Services.RegisterAnomalyModel("constant", func(config *Config.AnomalyDetectionConfig) Services.AnomalyModel {
+		return constantAnomalyModel{}
+	})
+
+	config := anomalyConfig()
+	config.MLModelType = "constant"
+	model, err := Services.NewAnomalyModel(config)
+	require.NoError(t, err)
+	assert.Equal(t, "constant", model.Name())
+
+	config.MLModelType = "isolation_forest"
+	_, err = Services.NewAnomalyModel(config)
+	assert.Error(t, err)
+}
@@ -0,0 +1,210 @@
+package Security
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupBehaviorDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "behavior.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.UserBehaviorProfile{}, &Models.SecurityEvent{}, &Models.LoginAttempt{}, &Models.User{}))
+	return db
+}
+
+func deviationTypes(deviations []Services.BehaviorDeviation) []string {
+	types := make([]string, 0, len(deviations))
+	for _, deviation := range deviations {
+		types = append(types, deviation.Type)
+	}
+	return types
+}
+
+// trainProfile 连续若干天在上午登录，并以每分钟2次的频率访问固定接口
+func trainProfile(t *testing.T, service *Services.BehaviorProfileService, userID uint, start time.Time) time.Time {
+	now := start
+	for day := 0; day < 12; day++ {
+		now = start.AddDate(0, 0, day)
+		_, _, err := service.Evaluate(context.Background(), Services.AnomalyObservation{UserID: userID, EventType: Services.BehaviorEventLogin, Resource: "login", IPAddress: "10.0.0.5", Time: now})
+		require.NoError(t, err)
+		for minute := 0; minute < 5; minute++ {
+			for i := 0; i < 2; i++ {
+				_, _, err := service.Evaluate(context.Background(), Services.AnomalyObservation{
+					UserID:    userID,
+					EventType: "http_request",
+					Resource:  fmt.Sprintf("/api/v1/posts/%d", day*10+i),
+					Action:    "GET",
+					IPAddress: "10.0.0.5",
+					Time:      now.Add(time.Duration(minute)*time.Minute + time.Duration(i)*time.Second),
+				})
+				require.NoError(t, err)
+			}
+		}
+	}
+	return now.Add(time.Hour)
+}
+
+func TestBehaviorProfileDeviations(t *testing.T) {
+	db := setupBehaviorDB(t)
+	config := &Config.AnomalyDetectionConfig{LearningMode: true, LearningPeriod: 24 * time.Hour}
+	service := Services.NewBehaviorProfileService(db, config)
+
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+
+	// 学习期内不判断偏离
+	deviations, score, err := service.Evaluate(context.Background(), Services.AnomalyObservation{UserID: 1, EventType: "http_request", Resource: "/admin", IPAddress: "8.8.8.8", Time: start})
+	require.NoError(t, err)
+	assert.Empty(t, deviations)
+	assert.Zero(t, score)
+
+	now := trainProfile(t, service, 1, start)
+
+	// 常用时段、常用IP和接口没有偏离，路径中的ID统一归类
+	deviations, _, err = service.Evaluate(context.Background(), Services.AnomalyObservation{UserID: 1, EventType: "http_request", Resource: "/api/v1/posts/999", Action: "GET", IPAddress: "10.0.0.7", Time: now})
+	require.NoError(t, err)
+	assert.Equal(t, []string{Services.BehaviorDeviationNewIP}, deviationTypes(deviations))
+
+	// 凌晨从新网络登录
+	night := time.Date(2024, 3, 16, 3, 0, 0, 0, time.UTC)
+	deviations, score, err = service.Evaluate(context.Background(), Services.AnomalyObservation{UserID: 1, EventType: Services.BehaviorEventLogin, Resource: "login", IPAddress: "203.0.113.9", Time: night})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{Services.BehaviorDeviationLoginHour, Services.BehaviorDeviationNewNetwork}, deviationTypes(deviations))
+	assert.InDelta(t, 1-(1-0.4)*(1-0.5), score, 1e-9)
+	for _, deviation := range deviations {
+		if deviation.Type == Services.BehaviorDeviationLoginHour {
+			assert.Equal(t, "03:00", deviation.Observed)
+			assert.Contains(t, deviation.Baseline, "09:00")
+		}
+	}
+
+	// 请求频率突增和新接口
+	var last []Services.BehaviorDeviation
+	burst := night.Add(time.Hour)
+	for i := 0; i < 20; i++ {
+		last, _, err = service.Evaluate(context.Background(), Services.AnomalyObservation{UserID: 1, EventType: "http_request", Resource: "/api/v1/users/export", Action: "POST", IPAddress: "10.0.0.5", Time: burst.Add(time.Duration(i) * time.Second)})
+		require.NoError(t, err)
+	}
+	assert.Contains(t, deviationTypes(last), Services.BehaviorDeviationRequestRate)
+	assert.NotContains(t, deviationTypes(last), Services.BehaviorDeviationNewEndpoint)
+
+	// 画像写入后，新实例从数据库加载
+	require.NoError(t, service.Flush(context.Background()))
+	reloaded := Services.NewBehaviorProfileService(db, config)
+	profile, err := reloaded.GetProfile(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(13), profile.LoginCount)
+	assert.Equal(t, int64(12), profile.LoginHours[9])
+	assert.Contains(t, profile.Endpoints, "GET /api/v1/posts/:id")
+	assert.Contains(t, profile.Networks, "10.0.0.0/24")
+	assert.InDelta(t, 2, profile.RateMean, 0.5)
+}
+
+func TestBehaviorProfileNetworkResolver(t *testing.T) {
+	service := Services.NewBehaviorProfileService(setupBehaviorDB(t), nil)
+	service.SetNetworkResolver(func(ip string) string { return "AS64500" })
+
+	_, _, err := service.Evaluate(context.Background(), Services.AnomalyObservation{UserID: 2, EventType: "http_request", IPAddress: "192.0.2.1", Time: time.Now()})
+	require.NoError(t, err)
+	profile, err := service.GetProfile(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"AS64500": 1}, profile.Networks)
+}
+
+func TestBehaviorProfileThrottledSaves(t *testing.T) {
+	db := setupBehaviorDB(t)
+	service := Services.NewBehaviorProfileService(db, nil)
+	service.SetSaveInterval(time.Hour)
+
+	storedEvents := func(userID uint) int64 {
+		var profile Models.UserBehaviorProfile
+		require.NoError(t, db.Where("user_id = ?", userID).First(&profile).Error)
+		return profile.EventCount
+	}
+
+	// 首个事件立即写入，间隔内的后续事件只更新内存
+	now := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		_, _, err := service.Evaluate(context.Background(), Services.AnomalyObservation{UserID: 1, EventType: "http_request", IPAddress: "10.0.0.5", Time: now.Add(time.Duration(i) * time.Second)})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(1), storedEvents(1))
+	profile, err := service.GetProfile(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), profile.EventCount)
+
+	// Flush 写入未保存的更新，已创建的记录被更新而不是重复插入
+	require.NoError(t, service.Flush(context.Background()))
+	assert.Equal(t, int64(5), storedEvents(1))
+	var count int64
+	require.NoError(t, db.Model(&Models.UserBehaviorProfile{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestBehaviorProfileCacheBound(t *testing.T) {
+	db := setupBehaviorDB(t)
+	service := Services.NewBehaviorProfileService(db, nil)
+	service.SetSaveInterval(time.Hour)
+	service.SetCacheLimits(2, time.Hour)
+
+	now := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	for _, userID := range []uint{1, 1, 2, 3} {
+		_, _, err := service.Evaluate(context.Background(), Services.AnomalyObservation{UserID: userID, EventType: "http_request", IPAddress: "10.0.0.5", Time: now})
+		require.NoError(t, err)
+	}
+
+	// 最久未访问的用户被淘汰，淘汰前写入未保存的更新
+	assert.Equal(t, 2, service.CachedProfiles())
+	var profile Models.UserBehaviorProfile
+	require.NoError(t, db.Where("user_id = ?", 1).First(&profile).Error)
+	assert.Equal(t, int64(2), profile.EventCount)
+
+	// 再次访问时从数据库加载
+	reloaded, err := service.GetProfile(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), reloaded.EventCount)
+	assert.Equal(t, 2, service.CachedProfiles())
+}
+
+func TestSecurityServiceLoginDeviationDetails(t *testing.T) {
+	db := setupBehaviorDB(t)
+	require.NoError(t, db.Create(&Models.User{UUID: "u-1", Username: "alice", Email: "alice@example.com", Password: "x"}).Error)
+
+	config := &Config.SecurityConfig{}
+	config.SetDefaults()
+	config.AnomalyDetection.LearningMode = false
+	service := Services.NewSecurityService(db, config)
+	defer service.Close()
+
+	// 先积累足够的正常请求
+	for i := 0; i < 60; i++ {
+		service.DetectAnomaly(context.Background(), 1, "http_request", "/api/v1/posts", "GET", "10.0.0.5", "test")
+	}
+	require.NoError(t, service.RecordLoginAttempt(context.Background(), "alice", "198.51.100.7", "test", "", true, "", ""))
+
+	var event Models.SecurityEvent
+	require.NoError(t, db.Where("event_type = ?", "unusual_login").First(&event).Error)
+	var details struct {
+		Deviations []Services.BehaviorDeviation `json:"deviations"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(event.Details), &details))
+	assert.Contains(t, deviationTypes(details.Deviations), Services.BehaviorDeviationNewNetwork)
+
+	profile, err := service.GetUserBehaviorProfile(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), profile.LoginCount)
+}
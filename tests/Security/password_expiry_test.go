@@ -0,0 +1,189 @@
+package Security
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type countingMailer struct {
+	sent []string
+}
+
+func (m *countingMailer) SendNotificationEmail(to, subject, body string) error {
+	m.sent = append(m.sent, to)
+	return nil
+}
+
+func setupPasswordExpiryDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "users.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.User{}))
+	return db
+}
+
+func passwordExpiryConfig() *Config.BaseSecurityConfig {
+	config := &Config.SecurityConfig{}
+	config.SetDefaults()
+	config.BaseSecurity.ForcePasswordChange = true
+	config.BaseSecurity.PasswordChangeInterval = 90 * 24 * time.Hour
+	config.BaseSecurity.PasswordExpiryWarning = 7 * 24 * time.Hour
+	return &config.BaseSecurity
+}
+
+func createPasswordUser(t *testing.T, db *gorm.DB, username string, changedAgo time.Duration) *Models.User {
+	changedAt := time.Now().Add(-changedAgo)
+	user := &Models.User{
+		Username:          username,
+		Email:             username + "@example.com",
+		Password:          "x",
+		Status:            1,
+		PasswordChangedAt: &changedAt,
+	}
+	require.NoError(t, db.Create(user).Error)
+	return user
+}
+
+func TestPasswordExpiryEnforce(t *testing.T) {
+	db := setupPasswordExpiryDB(t)
+	config := passwordExpiryConfig()
+	service := Services.NewPasswordExpiryService(db, config, &countingMailer{})
+
+	fresh := createPasswordUser(t, db, "fresh", 24*time.Hour)
+	stale := createPasswordUser(t, db, "stale", 91*24*time.Hour)
+
+	mustChange, err := service.Enforce(fresh.ID)
+	require.NoError(t, err)
+	assert.False(t, mustChange)
+
+	mustChange, err = service.Enforce(stale.ID)
+	require.NoError(t, err)
+	assert.True(t, mustChange)
+	var reloaded Models.User
+	require.NoError(t, db.First(&reloaded, stale.ID).Error)
+	assert.True(t, reloaded.MustChangePassword)
+
+	// 未修改过密码的用户按注册时间计算
+	legacy := &Models.User{Username: "legacy", Email: "legacy@example.com", Password: "x", Status: 1}
+	require.NoError(t, db.Create(legacy).Error)
+	require.NoError(t, db.Model(legacy).UpdateColumn("created_at", time.Now().Add(-100*24*time.Hour)).Error)
+	mustChange, _ = service.Enforce(legacy.ID)
+	assert.True(t, mustChange)
+
+	// 管理员标记的用户即使未过期也必须修改
+	require.NoError(t, service.RequirePasswordChange(fresh.ID))
+	mustChange, _ = service.Enforce(fresh.ID)
+	assert.True(t, mustChange)
+
+	// 未启用强制修改时只看标记
+	config.ForcePasswordChange = false
+	other := createPasswordUser(t, db, "other", 200*24*time.Hour)
+	mustChange, _ = service.Enforce(other.ID)
+	assert.False(t, mustChange)
+}
+
+func TestPasswordChangeClearsExpiry(t *testing.T) {
+	db := setupPasswordExpiryDB(t)
+	service := Services.NewPasswordExpiryService(db, passwordExpiryConfig(), &countingMailer{})
+
+	user := createPasswordUser(t, db, "alice", 120*24*time.Hour)
+	require.NoError(t, user.SetPassword("OldPassw0rd!"))
+	require.NoError(t, db.Model(user).Update("password", user.Password).Error)
+	mustChange, _ := service.Enforce(user.ID)
+	require.True(t, mustChange)
+
+	require.NoError(t, Services.NewUserServiceWithDB(db).ChangePassword(user.ID, "OldPassw0rd!", "N3w-Passw0rd!x"))
+
+	var reloaded Models.User
+	require.NoError(t, db.First(&reloaded, user.ID).Error)
+	assert.False(t, reloaded.MustChangePassword)
+	require.NotNil(t, reloaded.PasswordChangedAt)
+	assert.WithinDuration(t, time.Now(), *reloaded.PasswordChangedAt, time.Minute)
+	mustChange, _ = service.Enforce(user.ID)
+	assert.False(t, mustChange)
+}
+
+func TestPasswordExpiryNotifications(t *testing.T) {
+	db := setupPasswordExpiryDB(t)
+	mailer := &countingMailer{}
+	service := Services.NewPasswordExpiryService(db, passwordExpiryConfig(), mailer)
+
+	createPasswordUser(t, db, "fresh", 10*24*time.Hour)
+	soon := createPasswordUser(t, db, "soon", 85*24*time.Hour)
+	createPasswordUser(t, db, "expired", 95*24*time.Hour)
+
+	sent, err := service.NotifyExpiringPasswords()
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, []string{"soon@example.com"}, mailer.sent)
+
+	// 同一密码周期内不重复提醒
+	sent, err = service.NotifyExpiringPasswords()
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+
+	// 修改密码后进入新周期，再次临近过期时重新提醒
+	changedAt := time.Now().Add(-86 * 24 * time.Hour)
+	notifiedAt := changedAt.Add(-time.Hour)
+	require.NoError(t, db.Model(soon).Updates(map[string]interface{}{
+		"password_changed_at":         changedAt,
+		"password_expiry_notified_at": notifiedAt,
+	}).Error)
+	sent, err = service.NotifyExpiringPasswords()
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+}
+
+func TestPasswordExpiryMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupPasswordExpiryDB(t)
+	service := Services.NewPasswordExpiryService(db, passwordExpiryConfig(), &countingMailer{})
+	fresh := createPasswordUser(t, db, "fresh", time.Hour)
+	stale := createPasswordUser(t, db, "stale", 91*24*time.Hour)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", c.GetHeader("X-Test-User"))
+		c.Next()
+	})
+	router.Use(Middleware.NewPasswordExpiryMiddleware(service).Handle())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/api/v1/posts", ok)
+	router.POST("/api/v1/auth/change-password", ok)
+
+	request := func(method, path string, userID uint) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-Test-User", fmt.Sprint(userID))
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("GET", "/api/v1/posts", fresh.ID)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("X-Password-Change-Required"))
+
+	w = request("GET", "/api/v1/posts", stale.ID)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "PASSWORD_CHANGE_REQUIRED")
+
+	w = request("POST", "/api/v1/auth/change-password", stale.ID)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "true", w.Header().Get("X-Password-Change-Required"))
+}
@@ -0,0 +1,252 @@
+package Security
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupResponseDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "response.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&Models.SecurityResponseExecution{},
+		&Models.SecurityEvent{},
+		&Models.AccountLockout{},
+		&Models.ApiKey{},
+		&Models.UserBehaviorProfile{},
+	))
+	return db
+}
+
+func responseConfig() *Config.SecurityConfig {
+	config := &Config.SecurityConfig{}
+	config.SetDefaults()
+	config.Response.Enabled = true
+	return config
+}
+
+type recordingChannel struct {
+	name   string
+	alerts []Services.MonitoringAlert
+	err    error
+}
+
+func (c *recordingChannel) SendAlert(alert Services.MonitoringAlert) error {
+	c.alerts = append(c.alerts, alert)
+	return c.err
+}
+func (c *recordingChannel) GetName() string { return c.name }
+func (c *recordingChannel) IsEnabled() bool { return true }
+
+func TestAutoBlockOnAnomaly(t *testing.T) {
+	db := setupResponseDB(t)
+	config := &Config.SecurityConfig{}
+	config.SetDefaults()
+	config.AnomalyDetection.AutoBlockOnAnomaly = true
+	service := Services.NewSecurityService(db, config)
+	defer service.Close()
+
+	playbooks := service.GetResponseService().Playbooks()
+	require.Len(t, playbooks, 1)
+	assert.Equal(t, Services.AutoBlockPlaybookName, playbooks[0].Name)
+
+	// 风险评分低于阈值不触发
+	require.NoError(t, service.RecordSecurityEvent(context.Background(), 1, "anomaly_detected", "high", "203.0.113.1", "", "/api", "GET", "", 0.5, 0.5, false, true, "", ""))
+	assert.False(t, service.IsIPBlocked(context.Background(), "203.0.113.1"))
+
+	require.NoError(t, service.RecordSecurityEvent(context.Background(), 1, "anomaly_detected", "high", "203.0.113.2", "", "/api", "GET", "", 0.9, 0.9, false, true, "", ""))
+	assert.True(t, service.IsIPBlocked(context.Background(), "203.0.113.2"))
+	assert.False(t, service.IsIPBlocked(context.Background(), "203.0.113.3"))
+
+	// 冷却时间内重复事件不再触发
+	require.NoError(t, service.RecordSecurityEvent(context.Background(), 1, "anomaly_detected", "high", "203.0.113.2", "", "/api", "GET", "", 0.9, 0.9, false, true, "", ""))
+	executions, total, err := service.GetResponseService().ListExecutions("", 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, Models.ResponseStatusExecuted, executions[0].Status)
+	assert.Equal(t, Services.ResponseActionBlockIP, executions[0].Action)
+	assert.NotZero(t, executions[0].EventID)
+
+	// 登录检查同样拒绝被封禁的IP
+	allowed, _ := service.CheckLoginAttempts(context.Background(), "alice", "203.0.113.2")
+	assert.False(t, allowed)
+
+	// 封禁状态有缓存，解除锁定后立即失效
+	unlocked, err := service.GetAccountLockoutService().UnlockBy("", "203.0.113.2", 1, "误封")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), unlocked)
+	assert.False(t, service.IsIPBlocked(context.Background(), "203.0.113.2"))
+}
+
+func TestResponsePlaybookApproval(t *testing.T) {
+	db := setupResponseDB(t)
+	config := responseConfig()
+	service := Services.NewSecurityResponseService(db, config)
+	tokens := Services.NewTokenBlacklistService(nil)
+	service.SetTokenBlacklistService(tokens)
+	channel := &recordingChannel{name: "ops"}
+	service.AddNotificationChannel(channel)
+
+	require.NoError(t, service.SetPlaybooks([]Services.ResponsePlaybook{
+		{
+			Name:         "account_takeover",
+			EventTypes:   []string{"unusual_login"},
+			MinRiskScore: 0.5,
+			Actions: []Services.ResponsePlaybookAction{
+				{Type: Services.ResponseActionForceLogout},
+				{Type: Services.ResponseActionRequireMFA, Params: map[string]string{"duration": "1h"}},
+				{Type: Services.ResponseActionNotify, Params: map[string]string{"channel": "ops"}},
+			},
+		},
+		{Name: "ignored", EventTypes: []string{"http_request"}, Actions: []Services.ResponsePlaybookAction{{Type: Services.ResponseActionNotify}}},
+	}))
+
+	const userID = 9101
+	event := Services.ResponseEvent{ID: 7, EventType: "unusual_login", UserID: userID, IPAddress: "198.51.100.1", RiskScore: 0.8}
+	executions, err := service.HandleEvent(context.Background(), event)
+	require.NoError(t, err)
+	require.Len(t, executions, 3)
+
+	// 强制下线需要审批，MFA和通知直接执行
+	logout := executions[0]
+	assert.Equal(t, Models.ResponseStatusPendingApproval, logout.Status)
+	assert.NotNil(t, logout.ExpiresAt)
+	assert.False(t, tokens.IsUserTokenRevoked(userID, time.Now().Add(-time.Minute)))
+	assert.Equal(t, Models.ResponseStatusExecuted, executions[1].Status)
+	assert.True(t, tokens.IsMFAReverificationRequired(userID))
+	assert.Equal(t, Models.ResponseStatusExecuted, executions[2].Status)
+	require.Len(t, channel.alerts, 1)
+	assert.Equal(t, "account_takeover", channel.alerts[0].Metadata["playbook"])
+
+	approved, err := service.Approve(context.Background(), logout.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, Models.ResponseStatusExecuted, approved.Status)
+	assert.Equal(t, uint(1), *approved.ReviewedBy)
+	assert.True(t, tokens.IsUserTokenRevoked(userID, time.Now().Add(-time.Minute)))
+	assert.False(t, tokens.IsUserTokenRevoked(userID, time.Now().Add(time.Minute)))
+
+	_, err = service.Approve(context.Background(), logout.ID, 1)
+	assert.ErrorIs(t, err, Services.ErrResponseExecutionNotPending)
+	_, err = service.Approve(context.Background(), 999, 1)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+
+	// 其他用户的事件不受冷却影响，拒绝后不执行
+	executions, err = service.HandleEvent(context.Background(), Services.ResponseEvent{EventType: "unusual_login", UserID: userID + 1, RiskScore: 0.9})
+	require.NoError(t, err)
+	rejected, err := service.Reject(executions[0].ID, 1, "误报")
+	require.NoError(t, err)
+	assert.Equal(t, Models.ResponseStatusRejected, rejected.Status)
+	assert.False(t, tokens.IsUserTokenRevoked(userID+1, time.Now().Add(-time.Minute)))
+
+	list, total, err := service.ListExecutions(Models.ResponseStatusRejected, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "误报", list[0].Error)
+}
+
+func TestResponsePlaybookDryRunAndExpiry(t *testing.T) {
+	db := setupResponseDB(t)
+	config := responseConfig()
+	config.Response.ApprovalTimeout = time.Millisecond
+	service := Services.NewSecurityResponseService(db, config)
+
+	require.NoError(t, service.SetPlaybooks([]Services.ResponsePlaybook{
+		{Name: "rehearsal", DryRun: true, Actions: []Services.ResponsePlaybookAction{{Type: Services.ResponseActionBlockIP, AutoApprove: true}}},
+		{Name: "manual", EventTypes: []string{"*"}, Actions: []Services.ResponsePlaybookAction{{Type: Services.ResponseActionBlockIP}}},
+	}))
+
+	executions, err := service.HandleEvent(context.Background(), Services.ResponseEvent{EventType: "brute_force", IPAddress: "192.0.2.10", RiskScore: 1})
+	require.NoError(t, err)
+	require.Len(t, executions, 2)
+	assert.Equal(t, Models.ResponseStatusDryRun, executions[0].Status)
+	assert.Contains(t, executions[0].Result, "192.0.2.10")
+
+	var lockouts int64
+	db.Model(&Models.AccountLockout{}).Count(&lockouts)
+	assert.Zero(t, lockouts)
+
+	time.Sleep(5 * time.Millisecond)
+	_, err = service.Approve(context.Background(), executions[1].ID, 1)
+	assert.ErrorIs(t, err, Services.ErrResponseApprovalExpired)
+	db.Model(&Models.AccountLockout{}).Count(&lockouts)
+	assert.Zero(t, lockouts)
+}
+
+func TestResponseDisableAPIKeyAndNotifyFailure(t *testing.T) {
+	db := setupResponseDB(t)
+	service := Services.NewSecurityResponseService(db, responseConfig())
+	channel := &recordingChannel{name: "ops", err: errors.New("timeout")}
+	service.AddNotificationChannel(channel)
+
+	keys := []Models.ApiKey{
+		{UserID: 5, Name: "a", KeyHash: "h1", Prefix: "p1", Status: 1},
+		{UserID: 5, Name: "b", KeyHash: "h2", Prefix: "p2", Status: 1},
+		{UserID: 6, Name: "c", KeyHash: "h3", Prefix: "p3", Status: 1},
+	}
+	require.NoError(t, db.Create(&keys).Error)
+
+	require.NoError(t, service.SetPlaybooks([]Services.ResponsePlaybook{{
+		Name: "leaked_key",
+		Actions: []Services.ResponsePlaybookAction{
+			{Type: Services.ResponseActionDisableAPIKey, AutoApprove: true},
+			{Type: Services.ResponseActionNotify},
+		},
+	}}))
+
+	executions, err := service.HandleEvent(context.Background(), Services.ResponseEvent{EventType: "api_key_abuse", UserID: 5, RiskScore: 1})
+	require.NoError(t, err)
+	require.Len(t, executions, 2)
+	assert.Equal(t, Models.ResponseStatusExecuted, executions[0].Status)
+	assert.Contains(t, executions[0].Result, "2 个API密钥")
+	assert.Equal(t, Models.ResponseStatusFailed, executions[1].Status)
+	assert.Contains(t, executions[1].Error, "timeout")
+
+	var active int64
+	db.Model(&Models.ApiKey{}).Where("status = ?", 1).Count(&active)
+	assert.Equal(t, int64(1), active)
+}
+
+func TestResponsePlaybookLoading(t *testing.T) {
+	service := Services.NewSecurityResponseService(setupResponseDB(t), responseConfig())
+
+	err := service.SetPlaybooks([]Services.ResponsePlaybook{{Name: "bad", Actions: []Services.ResponsePlaybookAction{{Type: "quarantine"}}}})
+	assert.ErrorIs(t, err, Services.ErrUnknownResponseAction)
+	assert.Error(t, service.SetPlaybooks([]Services.ResponsePlaybook{{Name: "empty"}}))
+
+	path := filepath.Join(t.TempDir(), "playbooks.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[
+		{"name": "block", "event_types": ["anomaly_detected"], "min_risk_score": 0.8,
+		 "actions": [{"type": "block_ip", "params": {"duration": "1h"}, "auto_approve": true}]}
+	]`), 0644))
+	require.NoError(t, service.LoadPlaybooks(path))
+	playbooks := service.Playbooks()
+	require.Len(t, playbooks, 1)
+	assert.Equal(t, "1h", playbooks[0].Actions[0].Params["duration"])
+
+	// 自定义动作处理函数
+	var called bool
+	service.RegisterActionHandler("quarantine", func(ctx context.Context, request Services.ResponseActionRequest) (string, error) {
+		called = true
+		return "ok", nil
+	}, false)
+	require.NoError(t, service.SetPlaybooks([]Services.ResponsePlaybook{{Name: "custom", Actions: []Services.ResponsePlaybookAction{{Type: "quarantine"}}}}))
+	executions, err := service.HandleEvent(context.Background(), Services.ResponseEvent{EventType: "x"})
+	require.NoError(t, err)
+	assert.True(t, called)
+	assert.Equal(t, "ok", executions[0].Result)
+}
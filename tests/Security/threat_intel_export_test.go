@@ -0,0 +1,219 @@
+package Security
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupThreatDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "threat.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.ThreatIntelligence{}))
+
+	now := time.Now()
+	threats := []Models.ThreatIntelligence{
+		{Source: "AbuseIPDB", ThreatType: "malicious", Severity: "high", IPAddress: "1.2.3.4", Confidence: 0.8, Tags: "botnet, scanner", FirstSeen: now},
+		{Source: "feed", ThreatType: "phishing", Severity: "medium", Domain: "evil.example", URL: "http://evil.example/login?a='1'", Confidence: 90},
+		{Source: "feed", ThreatType: "malware", Severity: "critical", Hash: "d41d8cd98f00b204e9800998ecf8427e"},
+		{Source: "feed", ThreatType: "malicious", Severity: "low", IPAddress: "2001:db8::1"},
+		{Source: "feed", ThreatType: "malicious", Severity: "high", IPAddress: "5.6.7.8", Active: true},
+	}
+	require.NoError(t, db.Create(&threats).Error)
+	// 停用最后一条，导出时应被排除
+	require.NoError(t, db.Model(&threats[4]).Update("active", false).Error)
+	return db
+}
+
+type jsonExport struct {
+	Indicators []Services.ThreatIndicator `json:"indicators"`
+	Count      int                        `json:"count"`
+	NextCursor uint                       `json:"next_cursor"`
+}
+
+func exportJSON(t *testing.T, exporter *Services.ThreatIntelExporter, filter Services.ThreatIntelExportFilter) (jsonExport, *Services.ThreatIntelExportResult) {
+	var buf bytes.Buffer
+	result, err := exporter.Export(context.Background(), &buf, Services.ThreatIntelFormatJSON, filter)
+	require.NoError(t, err)
+	var data jsonExport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &data), buf.String())
+	return data, result
+}
+
+func TestThreatIntelExportPaginationAndFilters(t *testing.T) {
+	exporter := Services.NewThreatIntelExporter(setupThreatDB(t))
+
+	// 第一页2条记录，第二条记录包含域名和URL两个指标
+	page, result := exportJSON(t, exporter, Services.ThreatIntelExportFilter{Limit: 2})
+	assert.Equal(t, 2, result.Records)
+	assert.Equal(t, 3, result.Indicators)
+	assert.Equal(t, uint(2), result.NextCursor)
+	assert.Equal(t, uint(2), page.NextCursor)
+	assert.Equal(t, 3, page.Count)
+	assert.Equal(t, "1.2.3.4", page.Indicators[0].Value)
+	assert.Equal(t, 80, page.Indicators[0].Confidence)
+	assert.Equal(t, []string{"botnet", "scanner"}, page.Indicators[0].Tags)
+
+	// 最后一页没有下一页游标，停用的情报不导出
+	page, result = exportJSON(t, exporter, Services.ThreatIntelExportFilter{Limit: 2, AfterID: result.NextCursor})
+	assert.Equal(t, 2, result.Records)
+	assert.Zero(t, result.NextCursor)
+	assert.Equal(t, uint(4), result.LastID)
+
+	page, _ = exportJSON(t, exporter, Services.ThreatIntelExportFilter{IndicatorType: Services.IndicatorTypeIP, Severities: []string{"high", "low"}})
+	require.Len(t, page.Indicators, 2)
+	assert.Equal(t, "2001:db8::1", page.Indicators[1].Value)
+
+	page, _ = exportJSON(t, exporter, Services.ThreatIntelExportFilter{Since: time.Now().Add(time.Hour)})
+	assert.Empty(t, page.Indicators)
+
+	_, err := exporter.Export(context.Background(), io.Discard, "xml", Services.ThreatIntelExportFilter{})
+	assert.ErrorIs(t, err, Services.ErrUnsupportedThreatIntelFormat)
+	_, err = exporter.Export(context.Background(), io.Discard, Services.ThreatIntelFormatCSV, Services.ThreatIntelExportFilter{IndicatorType: "email"})
+	assert.ErrorIs(t, err, Services.ErrUnsupportedIndicatorType)
+}
+
+func TestThreatIntelExportCSVAndSTIX(t *testing.T) {
+	exporter := Services.NewThreatIntelExporter(setupThreatDB(t))
+
+	var buf bytes.Buffer
+	_, err := exporter.Export(context.Background(), &buf, Services.ThreatIntelFormatCSV, Services.ThreatIntelExportFilter{})
+	require.NoError(t, err)
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 6)
+	assert.Equal(t, "value", records[0][2])
+	assert.Equal(t, []string{"1", "ip", "1.2.3.4"}, records[1][:3])
+	assert.Equal(t, "botnet;scanner", records[1][8])
+
+	buf.Reset()
+	_, err = exporter.Export(context.Background(), &buf, Services.ThreatIntelFormatSTIX, Services.ThreatIntelExportFilter{})
+	require.NoError(t, err)
+	var bundle struct {
+		Type    string                   `json:"type"`
+		ID      string                   `json:"id"`
+		Objects []Services.STIXIndicator `json:"objects"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &bundle))
+	assert.Equal(t, "bundle", bundle.Type)
+	assert.Regexp(t, `^bundle--[0-9a-f-]{36}$`, bundle.ID)
+	require.Len(t, bundle.Objects, 5)
+
+	patterns := make([]string, 0, len(bundle.Objects))
+	for _, object := range bundle.Objects {
+		assert.Equal(t, "indicator", object.Type)
+		assert.Equal(t, "2.1", object.SpecVersion)
+		assert.Equal(t, "stix", object.PatternType)
+		assert.Regexp(t, `^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{3}Z$`, object.ValidFrom)
+		patterns = append(patterns, object.Pattern)
+	}
+	assert.Equal(t, []string{
+		"[ipv4-addr:value = '1.2.3.4']",
+		"[domain-name:value = 'evil.example']",
+		`[url:value = 'http://evil.example/login?a=\'1\'']`,
+		"[file:hashes.'MD5' = 'd41d8cd98f00b204e9800998ecf8427e']",
+		"[ipv6-addr:value = '2001:db8::1']",
+	}, patterns)
+
+	// 相同指标的ID保持不变
+	indicator := Services.ThreatIndicator{Type: Services.IndicatorTypeIP, Value: "1.2.3.4"}
+	assert.Equal(t, bundle.Objects[0].ID, Services.NewSTIXIndicator(indicator).ID)
+}
+
+func TestThreatIntelSharingPush(t *testing.T) {
+	db := setupThreatDB(t)
+
+	var mu sync.Mutex
+	var bodies [][]byte
+	var signatures []string
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		bodies = append(bodies, body)
+		signatures = append(signatures, r.Header.Get("X-Signature-256"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+	}))
+	defer server.Close()
+
+	config := &Config.ThreatIntelSharingConfig{
+		Enabled:    true,
+		WebhookURL: server.URL,
+		Format:     Services.ThreatIntelFormatJSON,
+		Secret:     "s3cret",
+		Interval:   time.Minute,
+		BatchSize:  2,
+	}
+	monitoring := &Config.MonitoringConfig{}
+	monitoring.SetDefaults()
+	monitoring.OutboundHTTP.MaxRetries = 0
+	service := Services.NewThreatIntelSharingService(db, config, Services.NewOutboundHTTPClient(monitoring))
+
+	// 默认只推送启动之后新增的情报
+	pushed, err := service.Push(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, pushed)
+	assert.Equal(t, uint(5), service.GetStatus().Cursor)
+
+	// 从头推送时按批次分多次请求
+	service.SetCursor(0)
+	pushed, err = service.Push(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 5, pushed)
+	require.Len(t, bodies, 2)
+	assert.Equal(t, Services.SignThreatIntelPayload("s3cret", bodies[0]), signatures[0])
+	var first jsonExport
+	require.NoError(t, json.Unmarshal(bodies[0], &first))
+	assert.Equal(t, 3, first.Count)
+
+	// 推送失败时不前移游标
+	require.NoError(t, db.Create(&Models.ThreatIntelligence{Source: "feed", ThreatType: "malicious", Severity: "high", IPAddress: "9.9.9.9"}).Error)
+	mu.Lock()
+	fail = true
+	mu.Unlock()
+	_, err = service.Push(context.Background())
+	assert.Error(t, err)
+	status := service.GetStatus()
+	assert.Equal(t, uint(4), status.Cursor)
+	assert.NotEmpty(t, status.LastError)
+	assert.Equal(t, int64(1), status.FailedRequests)
+
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+	pushed, err = service.Push(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, pushed)
+	status = service.GetStatus()
+	assert.Equal(t, uint(6), status.Cursor)
+	assert.Equal(t, int64(6), status.TotalPushed)
+	assert.Empty(t, status.LastError)
+
+	config.Enabled = false
+	_, err = service.Push(context.Background())
+	assert.ErrorIs(t, err, Services.ErrThreatIntelSharingDisabled)
+}
@@ -0,0 +1,273 @@
+package SecurityArchive
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+type archiveEnv struct {
+	db      *gorm.DB
+	config  *Config.SecurityArchiveConfig
+	root    string
+	store   *Services.LocalArchiveStore
+	service *Services.SecurityArchiveService
+	now     time.Time
+}
+
+func setup(t *testing.T) *archiveEnv {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "archive.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.AuditLog{}, &Models.SecurityEvent{}, &Models.LoginAttempt{}, &Models.SecurityArchiveFile{}))
+
+	config := &Config.SecurityConfig{}
+	config.SetDefaults()
+	config.Archive.Enabled = true
+	config.Archive.Retention = 30 * 24 * time.Hour
+	config.Archive.RowsPerFile = 2
+	config.Archive.MaxSearchFiles = 10
+
+	root := t.TempDir()
+	store := Services.NewLocalArchiveStore(root)
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	service := Services.NewSecurityArchiveService(db, &config.Archive, store)
+	service.SetClock(func() time.Time { return now })
+	return &archiveEnv{db: db, config: &config.Archive, root: root, store: store, service: service, now: now}
+}
+
+// seed 写入 expired 条过期和1条未过期的审计日志、安全事件和登录尝试
+func (e *archiveEnv) seed(t *testing.T, expired int) {
+	for i := 0; i < expired+1; i++ {
+		at := e.now.AddDate(0, 0, -60+i)
+		if i == expired {
+			at = e.now.AddDate(0, 0, -1)
+		}
+		require.NoError(t, e.db.Create(&Models.AuditLog{
+			UserID: uint(i + 1), Username: fmt.Sprintf("user%d", i+1), Action: "login",
+			IPAddress: "10.0.0.1", Description: fmt.Sprintf("操作 %d", i), CreatedAt: at,
+		}).Error)
+		require.NoError(t, e.db.Create(&Models.SecurityEvent{
+			EventType: "brute_force", EventLevel: "high", IPAddress: "192.168.1.9", Details: "多次登录失败", CreatedAt: at,
+		}).Error)
+		require.NoError(t, e.db.Create(&Models.LoginAttempt{
+			Username: "admin", IPAddress: "172.16.0.5", AttemptTime: at,
+		}).Error)
+	}
+}
+
+func count(t *testing.T, db *gorm.DB, model interface{}) int64 {
+	var n int64
+	require.NoError(t, db.Unscoped().Model(model).Count(&n).Error)
+	return n
+}
+
+func TestArchiveExportsExpiredRowsBeforeDelete(t *testing.T) {
+	env := setup(t)
+	env.seed(t, 5)
+	// 软删除的记录同样归档
+	require.NoError(t, env.db.Where("user_id = ?", 1).Delete(&Models.AuditLog{}).Error)
+
+	deleted, err := env.service.Archive(context.Background())
+	require.NoError(t, err)
+	assert.EqualValues(t, 15, deleted)
+	assert.EqualValues(t, 1, count(t, env.db, &Models.AuditLog{}))
+	assert.EqualValues(t, 1, count(t, env.db, &Models.SecurityEvent{}))
+	assert.EqualValues(t, 1, count(t, env.db, &Models.LoginAttempt{}))
+
+	manifest, err := env.service.Manifest(context.Background())
+	require.NoError(t, err)
+	require.Len(t, manifest.Files, 9, "每个表5条记录按每文件2条分为3个文件")
+	var rows int64
+	for _, file := range manifest.Files {
+		rows += file.Rows
+		assert.NotEmpty(t, file.Checksum)
+		assert.True(t, file.To.Before(env.now.Add(-30*24*time.Hour)))
+		_, err := os.Stat(filepath.Join(env.root, file.Key))
+		assert.NoError(t, err)
+	}
+	assert.EqualValues(t, 15, rows)
+	assert.EqualValues(t, 9, count(t, env.db, &Models.SecurityArchiveFile{}), "每个文件一条索引记录")
+
+	// 再次运行没有过期记录，索引不变
+	deleted, err = env.service.Archive(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+	manifest, err = env.service.Manifest(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, manifest.Files, 9)
+
+	files, err := env.service.Files(context.Background(), "audit_logs", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	assert.True(t, files[0].To.After(files[2].To), "按记录时间倒序")
+	_, err = env.service.Files(context.Background(), "users", time.Time{}, time.Time{})
+	assert.ErrorIs(t, err, Services.ErrSecurityArchiveTableUnknown)
+}
+
+func TestArchiveIndexSharedAcrossInstances(t *testing.T) {
+	env := setup(t)
+	env.seed(t, 4)
+
+	// 两个实例分别归档不同的表，索引记录互不覆盖
+	other := Services.NewSecurityArchiveService(env.db, env.config, env.store)
+	other.SetClock(func() time.Time { return env.now })
+	env.service.ExcludeTables("login_attempts")
+	other.ExcludeTables("audit_logs", "security_events")
+	_, err := other.Manifest(context.Background())
+	require.NoError(t, err)
+	_, err = env.service.Archive(context.Background())
+	require.NoError(t, err)
+	_, err = other.Archive(context.Background())
+	require.NoError(t, err)
+
+	manifest, err := env.service.Manifest(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, manifest.Files, 6, "每个表4条记录按每文件2条分为2个文件")
+	assert.Equal(t, env.now, manifest.UpdatedAt)
+}
+
+func TestArchiveImportsLegacyManifest(t *testing.T) {
+	env := setup(t)
+	legacy := Services.SecurityArchiveManifest{Files: []Services.SecurityArchiveFile{{
+		Key: "security/audit_logs/2026-01/1-2.jsonl.gz", Table: "audit_logs", Rows: 2, FirstID: 1, LastID: 2,
+		From: env.now.AddDate(0, -5, 0), To: env.now.AddDate(0, -5, 1), Checksum: "abc",
+	}}}
+	data, err := json.Marshal(legacy)
+	require.NoError(t, err)
+	require.NoError(t, env.store.Put(context.Background(), "security/manifest.json", data))
+
+	files, err := env.service.Files(context.Background(), "audit_logs", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "security/audit_logs/2026-01/1-2.jsonl.gz", files[0].Key)
+
+	// 重复导入不产生重复的索引项
+	again := Services.NewSecurityArchiveService(env.db, env.config, env.store)
+	_, err = again.Manifest(context.Background())
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, count(t, env.db, &Models.SecurityArchiveFile{}))
+}
+
+func TestArchiveSkipsExcludedTablesAndKeepsRowsWithoutStore(t *testing.T) {
+	env := setup(t)
+	env.seed(t, 2)
+	env.service.ExcludeTables("security_events")
+	assert.Equal(t, []string{"audit_logs", "login_attempts"}, env.service.Tables())
+
+	_, err := env.service.Archive(context.Background())
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, count(t, env.db, &Models.SecurityEvent{}), "已由大表分区归档管理的表不删除")
+
+	// 归档存储不可用时不删除记录
+	config := &Config.SecurityConfig{}
+	config.SetDefaults()
+	broken := Services.NewSecurityArchiveService(env.db, &config.Archive, nil)
+	_, err = broken.Archive(context.Background())
+	assert.Error(t, err)
+}
+
+func TestSearchArchive(t *testing.T) {
+	env := setup(t)
+	env.seed(t, 5)
+	_, err := env.service.Archive(context.Background())
+	require.NoError(t, err)
+
+	result, err := env.service.Search(context.Background(), Services.SecurityArchiveQuery{
+		Table:   "audit_logs",
+		Filters: map[string]string{"user_id": "3"},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Records, 1)
+	assert.Equal(t, "user3", result.Records[0].Record["username"])
+	assert.Equal(t, "audit_logs", result.Records[0].Table)
+	assert.Equal(t, 3, result.FilesScanned)
+	assert.False(t, result.Truncated)
+
+	// 关键字不区分大小写，时间范围只选择重叠的文件
+	result, err = env.service.Search(context.Background(), Services.SecurityArchiveQuery{
+		Text: "BRUTE_FORCE",
+		From: env.now.AddDate(0, 0, -59),
+		To:   env.now.AddDate(0, 0, -56),
+	})
+	require.NoError(t, err)
+	assert.Len(t, result.Records, 3)
+	assert.Equal(t, 6, result.FilesMatched, "每个表有2个文件与时间范围重叠")
+	for _, record := range result.Records {
+		assert.Equal(t, "security_events", record.Table)
+	}
+
+	result, err = env.service.Search(context.Background(), Services.SecurityArchiveQuery{Limit: 4})
+	require.NoError(t, err)
+	assert.Len(t, result.Records, 4)
+	assert.True(t, result.Truncated)
+
+	// 归档文件被修改时拒绝返回
+	files, err := env.service.Files(context.Background(), "login_attempts", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.NoError(t, env.store.Put(context.Background(), files[0].Key, []byte("tampered")))
+	_, err = env.service.Search(context.Background(), Services.SecurityArchiveQuery{Table: "login_attempts"})
+	assert.ErrorIs(t, err, Services.ErrSecurityArchiveChecksum)
+}
+
+func TestSecurityArchiveEndpoints(t *testing.T) {
+	env := setup(t)
+	env.seed(t, 3)
+	_, err := env.service.Archive(context.Background())
+	require.NoError(t, err)
+
+	controller := Controllers.NewSecurityArchiveController(env.service)
+	engine := gin.New()
+	engine.GET("/archive/files", controller.GetArchiveFiles)
+	engine.GET("/archive/search", controller.SearchArchive)
+	do := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := do("/archive/search?table=audit_logs&filter[username]=user2&q=%E6%93%8D%E4%BD%9C")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Data Services.SecurityArchiveSearchResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data.Records, 1)
+	assert.Equal(t, "操作 1", resp.Data.Records[0].Record["description"])
+
+	w = do("/archive/files?table=login_attempts")
+	require.Equal(t, http.StatusOK, w.Code)
+	var files struct {
+		Data struct {
+			Files []Services.SecurityArchiveFile `json:"files"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &files))
+	assert.Len(t, files.Data.Files, 2)
+
+	assert.Equal(t, http.StatusNotFound, do("/archive/search?table=users").Code)
+	assert.Equal(t, http.StatusBadRequest, do("/archive/search?start_time=yesterday").Code)
+	assert.Equal(t, http.StatusBadRequest, do("/archive/search?limit=-1").Code)
+}
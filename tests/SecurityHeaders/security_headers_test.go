@@ -0,0 +1,217 @@
+package SecurityHeaders
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Http/Routes"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Services"
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func defaultSecurityConfig() *Config.SecurityConfig {
+	config := &Config.SecurityConfig{}
+	config.SetDefaults()
+	return config
+}
+
+func headersEngine(config *Config.SecurityConfig) *gin.Engine {
+	engine := gin.New()
+	engine.Use(Middleware.NewSecurityHeadersMiddleware(config).Handle())
+	engine.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+	return engine
+}
+
+func get(engine *gin.Engine, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestSecurityHeadersFromConfig(t *testing.T) {
+	config := defaultSecurityConfig()
+	config.ThreatProtection.CSPDirectives = "default-src 'self';  script-src 'self'  https://cdn.example.com ;"
+	config.Headers.FrameOptions = "sameorigin"
+	config.Headers.ReferrerPolicy = "no-referrer"
+	config.Headers.PermissionsPolicy = "camera=()"
+	engine := headersEngine(config)
+
+	w := get(engine, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "default-src 'self'; script-src 'self' https://cdn.example.com; report-uri /api/v1/csp-report", w.Header().Get("Content-Security-Policy"))
+	assert.Empty(t, w.Header().Get("Content-Security-Policy-Report-Only"))
+	assert.Equal(t, "SAMEORIGIN", w.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "no-referrer", w.Header().Get("Referrer-Policy"))
+	assert.Equal(t, "camera=()", w.Header().Get("Permissions-Policy"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	// 普通HTTP请求不设置HSTS
+	assert.Empty(t, w.Header().Get("Strict-Transport-Security"))
+
+	// 反向代理终止TLS
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	w = get(engine, req)
+	assert.Equal(t, "max-age=31536000; includeSubDomains", w.Header().Get("Strict-Transport-Security"))
+
+	// 直接HTTPS请求
+	req = httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.TLS = &tls.ConnectionState{}
+	w = get(engine, req)
+	assert.Equal(t, "max-age=31536000; includeSubDomains", w.Header().Get("Strict-Transport-Security"))
+
+	// 只上报模式、预加载和关闭CSP
+	config.Headers.CSPReportOnly = true
+	config.Headers.CSPReportURI = ""
+	config.Headers.HSTSMaxAge = 2 * 365 * 24 * time.Hour
+	config.Headers.HSTSPreload = true
+	engine = headersEngine(config)
+	w = get(engine, req)
+	assert.Empty(t, w.Header().Get("Content-Security-Policy"))
+	assert.Equal(t, "default-src 'self'; script-src 'self' https://cdn.example.com", w.Header().Get("Content-Security-Policy-Report-Only"))
+	assert.Equal(t, "max-age=63072000; includeSubDomains; preload", w.Header().Get("Strict-Transport-Security"))
+
+	config.ThreatProtection.ContentSecurityPolicy = false
+	config.Headers.ReferrerPolicy = ""
+	w = get(headersEngine(config), req)
+	assert.Empty(t, w.Header().Get("Content-Security-Policy-Report-Only"))
+	assert.Empty(t, w.Header().Get("Referrer-Policy"))
+	assert.Equal(t, "SAMEORIGIN", w.Header().Get("X-Frame-Options"))
+
+	config.Headers.Enabled = false
+	w = get(headersEngine(config), req)
+	assert.Empty(t, w.Header().Get("X-Frame-Options"))
+	assert.Empty(t, w.Header().Get("Strict-Transport-Security"))
+}
+
+func TestBuildContentSecurityPolicyKeepsReportURI(t *testing.T) {
+	assert.Equal(t, "default-src 'none'; report-uri https://reports.example.com/csp",
+		Middleware.BuildContentSecurityPolicy("default-src 'none'; report-uri https://reports.example.com/csp", "/api/v1/csp-report"))
+	assert.Empty(t, Middleware.BuildContentSecurityPolicy(" ; ", "/api/v1/csp-report"))
+}
+
+func TestSecurityHeadersConfigValidate(t *testing.T) {
+	config := defaultSecurityConfig()
+	config.BaseSecurity.ConcurrentSessionLimit = 3
+	config.BaseSecurity.RateLimitRequests = 100
+	config.PasswordPolicy.MinLength = 8
+	config.PasswordPolicy.MaxLength = 128
+	config.PasswordPolicy.MaxRepeatedChars = 3
+	config.ThreatProtection.MaxFileSize = 1024
+	require.NoError(t, config.Validate())
+
+	config.Headers.FrameOptions = "ALLOW-FROM https://example.com"
+	assert.Error(t, config.Validate())
+	config.Headers.FrameOptions = "DENY"
+
+	config.Headers.HSTSPreload = true
+	config.Headers.HSTSMaxAge = 24 * time.Hour
+	assert.Error(t, config.Validate())
+	config.Headers.HSTSMaxAge = 365 * 24 * time.Hour
+	require.NoError(t, config.Validate())
+
+	config.Headers.CSPReportMaxBytes = 0
+	assert.Error(t, config.Validate())
+}
+
+func setupReports(t *testing.T, maxBytes int64) (*gorm.DB, *gin.Engine) {
+	OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "测试", Version: "1.0.0"}))
+	t.Cleanup(func() {
+		OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "Cloud Platform API", Version: "1.0.0"}))
+	})
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "csp.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.SecurityEvent{}))
+
+	engine := gin.New()
+	Routes.RegisterCSPReportRoutes(engine, Controllers.NewCSPReportController(Services.NewCSPReportService(db), maxBytes))
+	return db, engine
+}
+
+func postReport(engine *gin.Engine, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/csp-report", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "TestBrowser/1.0")
+	return get(engine, req)
+}
+
+func TestCSPReportStoredAsSecurityEvent(t *testing.T) {
+	db, engine := setupReports(t, 0)
+
+	w := postReport(engine, "application/csp-report", `{"csp-report": {
+		"document-uri": "https://app.example.com/dashboard",
+		"referrer": "",
+		"violated-directive": "script-src 'self'",
+		"original-policy": "default-src 'self'; report-uri /api/v1/csp-report",
+		"blocked-uri": "https://evil.example.com/x.js",
+		"line-number": 12,
+		"status-code": 200
+	}}`)
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+
+	w = postReport(engine, "application/reports+json", `[
+		{"type": "csp-violation", "url": "https://app.example.com/settings", "body": {
+			"blockedURL": "inline", "effectiveDirective": "style-src-elem", "disposition": "report", "sample": "body{}"}},
+		{"type": "deprecation", "url": "https://app.example.com/settings", "body": {"id": "x"}}
+	]`)
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+
+	var events []Models.SecurityEvent
+	require.NoError(t, db.Order("id").Find(&events).Error)
+	require.Len(t, events, 2)
+
+	assert.Equal(t, Services.CSPViolationEventType, events[0].EventType)
+	assert.Equal(t, Services.CSPViolationEventLevel, events[0].EventLevel)
+	assert.Nil(t, events[0].UserID)
+	assert.Equal(t, "https://evil.example.com/x.js", events[0].Resource)
+	assert.Equal(t, "script-src", events[0].Action)
+	assert.Equal(t, "TestBrowser/1.0", events[0].UserAgent)
+	var report Services.CSPViolationReport
+	require.NoError(t, json.Unmarshal([]byte(events[0].Details), &report))
+	assert.Equal(t, "https://app.example.com/dashboard", report.DocumentURI)
+	assert.Equal(t, 12, report.LineNumber)
+
+	assert.Equal(t, "inline", events[1].Resource)
+	assert.Equal(t, "style-src-elem", events[1].Action)
+	require.NoError(t, json.Unmarshal([]byte(events[1].Details), &report))
+	assert.Equal(t, "https://app.example.com/settings", report.DocumentURI)
+	assert.Equal(t, "report", report.Disposition)
+	assert.Equal(t, "body{}", report.ScriptSample)
+}
+
+func TestCSPReportRejectsInvalidAndOversizedBodies(t *testing.T) {
+	db, engine := setupReports(t, 256)
+
+	w := postReport(engine, "application/csp-report", `not json`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = postReport(engine, "application/csp-report", `{"other": {}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	large := `{"csp-report": {"document-uri": "https://app.example.com/", "blocked-uri": "` + strings.Repeat("a", 512) + `"}}`
+	w = postReport(engine, "application/csp-report", large)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	var count int64
+	require.NoError(t, db.Model(&Models.SecurityEvent{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
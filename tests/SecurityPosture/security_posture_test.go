@@ -0,0 +1,220 @@
+package SecurityPosture
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Routes"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"cloud-platform-api/app/Testing"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func setupDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "posture.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.User{}, &Models.ApiKey{}, &Models.SecurityAlert{}, &Models.SecurityEvent{},
+		&Models.SecurityPostureSnapshot{}, &Services.SecurityScanResult{}))
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// seed 创建4个正常用户（2个绑定手机号、2个密码合规）、3个启用的API密钥（1个过期、1个闲置）和2个未处理的高风险告警/事件
+func seed(t *testing.T, db *gorm.DB, now time.Time) {
+	factory := Testing.NewFactory(t, db)
+	factory.Admin()
+	factory.User(func(u *Models.User) { u.Phone = "+8613800000001" })
+	stale := factory.User(func(u *Models.User) { u.Phone = "+8613800000002" })
+	mustChange := factory.User()
+	disabled := factory.User(func(u *Models.User) { u.Phone = "+8613800000003" })
+	require.NoError(t, db.Model(stale).Update("password_changed_at", now.AddDate(0, 0, -100)).Error)
+	require.NoError(t, db.Model(mustChange).Update("must_change_password", true).Error)
+	require.NoError(t, db.Model(disabled).Update("status", 0).Error)
+
+	recent, old, expired := now.Add(-time.Hour), now.AddDate(0, 0, -100), now.Add(-time.Hour)
+	keys := []Models.ApiKey{
+		{UserID: stale.ID, Name: "recent", KeyHash: "h1", Prefix: "k1", LastUsedAt: &recent},
+		{UserID: stale.ID, Name: "stale", KeyHash: "h2", Prefix: "k2", LastUsedAt: &old},
+		{UserID: stale.ID, Name: "expired", KeyHash: "h3", Prefix: "k3", LastUsedAt: &recent, ExpiresAt: &expired},
+		{UserID: stale.ID, Name: "disabled", KeyHash: "h4", Prefix: "k4", LastUsedAt: &old},
+	}
+	require.NoError(t, db.Create(&keys).Error)
+	require.NoError(t, db.Model(&keys[3]).Update("status", 0).Error)
+
+	require.NoError(t, db.Create(&[]Models.SecurityAlert{
+		{AlertType: "brute_force", Severity: "high", Title: "未处理"},
+		{AlertType: "scan", Severity: "low", Title: "低风险"},
+		{AlertType: "malware", Severity: "critical", Title: "已解决", Resolved: true},
+	}).Error)
+	require.NoError(t, db.Create(&[]Models.SecurityEvent{
+		{EventType: "unusual_login", EventLevel: "high", CreatedAt: now.Add(-time.Hour)},
+		{EventType: "sql_injection", EventLevel: "critical", Blocked: true, CreatedAt: now.Add(-time.Hour)},
+		{EventType: "unusual_login", EventLevel: "high", CreatedAt: now.AddDate(0, 0, -30)},
+	}).Error)
+}
+
+func category(t *testing.T, posture *Services.SecurityPosture, key string) Services.PostureCategory {
+	for _, c := range posture.Categories {
+		if c.Key == key {
+			return c
+		}
+	}
+	t.Fatalf("缺少分类 %s", key)
+	return Services.PostureCategory{}
+}
+
+func TestSecurityPostureScoreAndTrend(t *testing.T) {
+	db := setupDB(t)
+	now := time.Now()
+	seed(t, db, now)
+	service := Services.NewSecurityPostureService(db, nil)
+	service.SetClock(func() time.Time { return now })
+
+	posture, err := service.Evaluate()
+	require.NoError(t, err)
+	assert.Equal(t, 50.0, category(t, posture, Services.PostureCategoryMFA).Score)
+	apiKeys := category(t, posture, Services.PostureCategoryAPIKeys)
+	assert.Equal(t, 33.3, apiKeys.Score)
+	assert.EqualValues(t, 3, apiKeys.Metrics["active_keys"])
+	assert.EqualValues(t, 1, apiKeys.Metrics["expired_keys"])
+	assert.EqualValues(t, 1, apiKeys.Metrics["stale_keys"])
+	assert.NotEmpty(t, apiKeys.Recommendation)
+	assert.Equal(t, 50.0, category(t, posture, Services.PostureCategoryPasswordPolicy).Score)
+	assert.Equal(t, 80.0, category(t, posture, Services.PostureCategoryHighRiskEvents).Score)
+	// 从未扫描时漏洞分项不参与计算
+	vulns := category(t, posture, Services.PostureCategoryVulnerabilities)
+	assert.False(t, vulns.Available)
+	// (50*25 + 33.3*15 + 50*15 + 80*20) / 75
+	assert.Equal(t, 54.7, posture.Score)
+	assert.Equal(t, "F", posture.Grade)
+
+	snapshot, err := service.RecordSnapshot()
+	require.NoError(t, err)
+	assert.Equal(t, 54.7, snapshot.Score)
+
+	require.NoError(t, db.Create(&[]Services.SecurityScanResult{
+		{ID: "old", Status: "completed", ScanTime: now.AddDate(0, 0, -7), Vulnerabilities: []Services.Vulnerability{
+			{Severity: "critical"}, {Severity: "critical"}, {Severity: "critical"},
+		}},
+		{ID: "latest", Status: "completed", ScanTime: now.Add(-time.Hour), Vulnerabilities: []Services.Vulnerability{
+			{Severity: "critical"}, {Severity: "high"}, {Severity: "low"},
+		}},
+	}).Error)
+
+	posture, err = service.Posture(0)
+	require.NoError(t, err)
+	vulns = category(t, posture, Services.PostureCategoryVulnerabilities)
+	assert.True(t, vulns.Available)
+	assert.Equal(t, 65.0, vulns.Score)
+	assert.Equal(t, "latest", vulns.Metrics["scan_id"])
+	assert.Equal(t, 57.2, posture.Score)
+	require.NotNil(t, posture.Trend)
+	assert.Equal(t, 54.7, posture.Trend.PreviousScore)
+	assert.Equal(t, 2.5, posture.Trend.Change)
+	assert.Equal(t, Services.PostureTrendUp, posture.Trend.Direction)
+	require.Len(t, posture.History, 1)
+	assert.Equal(t, 50.0, posture.History[0].CategoryScores[Services.PostureCategoryMFA])
+	_, hasVulns := posture.History[0].CategoryScores[Services.PostureCategoryVulnerabilities]
+	assert.False(t, hasVulns)
+}
+
+func TestSecurityPostureHistoryRetention(t *testing.T) {
+	db := setupDB(t)
+	now := time.Now()
+	Testing.NewFactory(t, db).User()
+
+	config := &Config.SecurityConfig{}
+	config.SetDefaults()
+	config.Posture.HistoryRetention = 10 * 24 * time.Hour
+	config.Posture.VulnWeight = 0
+	service := Services.NewSecurityPostureService(db, config)
+
+	for _, daysAgo := range []int{40, 20, 5} {
+		at := now.AddDate(0, 0, -daysAgo)
+		service.SetClock(func() time.Time { return at })
+		_, err := service.RecordSnapshot()
+		require.NoError(t, err)
+	}
+	service.SetClock(func() time.Time { return now })
+
+	// 清理任务删除超过保留时间的快照
+	deleted, err := service.Cleanup(context.Background())
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, deleted)
+	var count int64
+	require.NoError(t, db.Model(&Models.SecurityPostureSnapshot{}).Count(&count).Error)
+	assert.EqualValues(t, 1, count)
+
+	history, err := service.History(3)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+	history, err = service.History(7)
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+}
+
+func TestSecurityPostureAPI(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-jwt-secret-key-for-testing-only-32-chars")
+	Config.LoadConfig()
+	OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "测试", Version: "1.0.0"}))
+	t.Cleanup(func() {
+		OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "Cloud Platform API", Version: "1.0.0"}))
+	})
+
+	db := setupDB(t)
+	factory := Testing.NewFactory(t, db)
+	admin := factory.Admin(func(u *Models.User) { u.Phone = "+8613800000001" })
+	user := factory.User()
+
+	engine := gin.New()
+	Routes.RegisterSecurityPostureRoutes(engine, Storage.NewStorageManager(&Config.StorageConfig{BasePath: t.TempDir()}),
+		Controllers.NewSecurityPostureController(Services.NewSecurityPostureService(db, nil)))
+	request := func(token, method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusForbidden, request(factory.Token(user), http.MethodGet, "/api/v1/security/posture").Code)
+
+	adminToken := factory.Token(admin)
+	w := request(adminToken, http.MethodPost, "/api/v1/security/posture/snapshots")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = request(adminToken, http.MethodGet, "/api/v1/security/posture?days=7")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		Data Services.SecurityPosture `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.Data.Categories, 5)
+	assert.Len(t, response.Data.History, 1)
+	require.NotNil(t, response.Data.Trend)
+	assert.Equal(t, Services.PostureTrendFlat, response.Data.Trend.Direction)
+	assert.Equal(t, 50.0, category(t, &response.Data, Services.PostureCategoryMFA).Score)
+}
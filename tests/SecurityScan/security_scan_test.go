@@ -0,0 +1,338 @@
+package SecurityScan
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Http/Routes"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"cloud-platform-api/app/Testing"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func setupDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "scan.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.User{}, &Models.ApiKey{}, &Models.SecurityScanResult{}))
+	previous := Database.DB
+	Database.DB = db
+	t.Cleanup(func() {
+		Database.DB = previous
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestOSVScannerReportsDependencyVulnerabilities(t *testing.T) {
+	var queried []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/querybatch":
+			var body struct {
+				Queries []struct {
+					Package struct {
+						Name      string `json:"name"`
+						Ecosystem string `json:"ecosystem"`
+					} `json:"package"`
+					Version string `json:"version"`
+				} `json:"queries"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			for _, q := range body.Queries {
+				assert.Equal(t, "Go", q.Package.Ecosystem)
+				queried = append(queried, q.Package.Name+"@"+q.Version)
+			}
+			w.Write([]byte(`{"results": [{"vulns": [{"id": "GO-2024-0001"}, {"id": "GHSA-xxxx"}]}, {}]}`))
+		case r.URL.Path == "/v1/vulns/GO-2024-0001":
+			w.Write([]byte(`{"id": "GO-2024-0001", "summary": "Request smuggling in example.com/web",
+				"aliases": ["CVE-2024-0001"],
+				"affected": [{"package": {"name": "example.com/web"}, "ranges": [{"events": [{"introduced": "0"}, {"fixed": "1.2.4"}]}]}]}`))
+		case r.URL.Path == "/v1/vulns/GHSA-xxxx":
+			w.Write([]byte(`{"id": "GHSA-xxxx", "details": "Denial of service", "database_specific": {"severity": "MODERATE"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	scanner := Services.NewOSVScanner(server.URL)
+	scanner.SetModules([]Services.OSVModule{{Path: "example.com/web", Version: "v1.2.3"}, {Path: "example.com/safe", Version: "v0.1.0"}})
+	vulns, err := scanner.Scan(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"example.com/web@1.2.3", "example.com/safe@0.1.0"}, queried)
+	require.Len(t, vulns, 2)
+
+	assert.Equal(t, "GO-2024-0001", vulns[0].ID)
+	assert.Equal(t, "dependency_vulnerability", vulns[0].Type)
+	// 漏洞库没有给出严重程度时按 high 处理
+	assert.Equal(t, "high", vulns[0].Severity)
+	assert.Equal(t, "Request smuggling in example.com/web", vulns[0].Description)
+	assert.Equal(t, "example.com/web@v1.2.3", vulns[0].Location)
+	assert.Contains(t, vulns[0].Recommendation, "v1.2.4")
+	assert.Contains(t, vulns[0].References, "CVE-2024-0001")
+
+	assert.Equal(t, "medium", vulns[1].Severity)
+	assert.Equal(t, "Denial of service", vulns[1].Description)
+}
+
+func TestTLSScannerChecksCertificateAndProtocols(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{MinVersion: tls.VersionTLS10}
+	server.StartTLS()
+	defer server.Close()
+	target := server.Listener.Addr().String()
+
+	types := func(vulns []Services.Vulnerability) map[string]string {
+		result := make(map[string]string)
+		for _, v := range vulns {
+			assert.Equal(t, target, v.Location)
+			result[v.Type] = v.Severity
+		}
+		return result
+	}
+
+	// 测试证书为自签名证书
+	vulns, err := Services.NewTLSScanner([]string{target}, time.Second, 30*24*time.Hour).Scan(context.Background())
+	require.NoError(t, err)
+	found := types(vulns)
+	assert.Equal(t, "high", found["tls_certificate_untrusted"])
+	assert.Equal(t, "medium", found["tls_legacy_protocol"])
+
+	// 信任测试证书后只报告旧协议和即将过期
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	scanner := Services.NewTLSScanner([]string{target}, time.Second, time.Until(server.Certificate().NotAfter)+time.Hour)
+	scanner.SetRootCAs(pool)
+	vulns, err = scanner.Scan(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"tls_legacy_protocol": "medium", "tls_certificate_expiring": "medium"}, types(vulns))
+
+	// 无法连接的目标作为插件错误返回
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closedAddr := closed.Addr().String()
+	closed.Close()
+	_, err = Services.NewTLSScanner([]string{closedAddr}, time.Second, 0).Scan(context.Background())
+	assert.ErrorContains(t, err, closedAddr)
+}
+
+func TestJWTSecretScanner(t *testing.T) {
+	cases := []struct {
+		secret   string
+		severity string
+	}{
+		{"", "critical"},
+		{"your-super-secret-jwt-key-change-in-production-must-be-at-least-32-characters-long", "critical"},
+		{"Secret", "critical"},
+		{"short-but-random-Xy9!", "high"},
+		{strings.Repeat("ab", 20), "medium"},
+		{"q8Vt3mZ0rL2xK9pW5sN7bY4cH1jF6dGe0aUi", ""},
+	}
+	for _, c := range cases {
+		vulns, err := Services.NewJWTSecretScanner(c.secret).Scan(context.Background())
+		require.NoError(t, err)
+		if c.severity == "" {
+			assert.Empty(t, vulns, c.secret)
+			continue
+		}
+		require.Len(t, vulns, 1, c.secret)
+		assert.Equal(t, c.severity, vulns[0].Severity, c.secret)
+		assert.Equal(t, "weak_jwt_secret", vulns[0].Type)
+		if c.secret != "" {
+			assert.NotContains(t, vulns[0].Description, c.secret)
+		}
+	}
+}
+
+func TestCORSScannerDetectsPermissivePolicy(t *testing.T) {
+	engine := gin.New()
+	engine.Use(Middleware.NewCORSMiddleware().Handle())
+	engine.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	vulns, err := Services.NewCORSScanner(engine, "/health", nil).Scan(context.Background())
+	require.NoError(t, err)
+	require.Len(t, vulns, 2)
+	assert.Equal(t, "permissive_cors", vulns[0].Type)
+	assert.Equal(t, "medium", vulns[0].Severity)
+	assert.Equal(t, "GET /health", vulns[0].Location)
+	assert.Contains(t, vulns[0].Description, "Access-Control-Allow-Credentials")
+
+	// 反射请求来源并允许凭证
+	reflecting := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", r.Header.Get("Origin"))
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	})
+	vulns, err = Services.NewCORSScanner(reflecting, "/", nil).Scan(context.Background())
+	require.NoError(t, err)
+	require.Len(t, vulns, 2)
+	assert.Equal(t, "high", vulns[0].Severity)
+
+	strict := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Origin") == "https://app.example.com" {
+			w.Header().Set("Access-Control-Allow-Origin", "https://app.example.com")
+		}
+	})
+	websocket := &Config.WebSocketConfig{EnableOriginCheck: true, AllowedOrigins: []string{"https://app.example.com"}}
+	vulns, err = Services.NewCORSScanner(strict, "/", websocket).Scan(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, vulns)
+
+	websocket.AllowedOrigins = append(websocket.AllowedOrigins, "*")
+	vulns, err = Services.NewCORSScanner(strict, "/", websocket).Scan(context.Background())
+	require.NoError(t, err)
+	require.Len(t, vulns, 1)
+	assert.Equal(t, "permissive_websocket_origin", vulns[0].Type)
+}
+
+func TestOpenPortScanner(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	openPort := listener.Addr().(*net.TCPAddr).Port
+
+	allowedListener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer allowedListener.Close()
+	allowedPort := allowedListener.Addr().(*net.TCPAddr).Port
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	scanner := Services.NewOpenPortScanner([]string{"127.0.0.1"}, []int{openPort, allowedPort, closedPort}, []int{allowedPort}, time.Second)
+	vulns, err := scanner.Scan(context.Background())
+	require.NoError(t, err)
+	require.Len(t, vulns, 1)
+	assert.Equal(t, "open_port", vulns[0].Type)
+	assert.Equal(t, "medium", vulns[0].Severity)
+	assert.Equal(t, listener.Addr().String(), vulns[0].Location)
+}
+
+type fakeScanner struct {
+	name    string
+	vulns   []Services.Vulnerability
+	err     error
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *fakeScanner) Name() string { return s.name }
+
+func (s *fakeScanner) Scan(ctx context.Context) ([]Services.Vulnerability, error) {
+	if s.started != nil {
+		close(s.started)
+		<-s.release
+	}
+	return s.vulns, s.err
+}
+
+func TestPerformSecurityScanRunsPlugins(t *testing.T) {
+	db := setupDB(t)
+	expired := time.Now().Add(-time.Hour)
+	user := Testing.NewFactory(t, db).User()
+	require.NoError(t, db.Create(&Models.ApiKey{UserID: user.ID, Name: "old", KeyHash: "h1", Prefix: "k1", ExpiresAt: &expired}).Error)
+
+	service := Services.NewSecurityAuditService(nil)
+	service.SetScanners([]Services.VulnerabilityScanner{
+		Services.NewAPIKeyExpiryScanner(db),
+		&fakeScanner{name: "partial", vulns: []Services.Vulnerability{{Type: "x", Severity: "critical"}}, err: errors.New("目标不可达")},
+	})
+	service.RegisterScanner(&fakeScanner{name: "broken", err: errors.New("超时")})
+
+	result, err := service.PerformSecurityScan()
+	require.NoError(t, err)
+	assert.Equal(t, "completed", result.Status)
+	require.Len(t, result.Vulnerabilities, 2)
+	assert.Equal(t, Services.ScannerAPIKeyExpiry, result.Vulnerabilities[0].Scanner)
+	assert.Equal(t, "partial", result.Vulnerabilities[1].Scanner)
+	assert.Equal(t, map[string]string{"partial": "目标不可达", "broken": "超时"}, result.Errors)
+	assert.Equal(t, 14, result.RiskScore)
+
+	stored, err := service.GetScanResult(result.ID)
+	require.NoError(t, err)
+	assert.Equal(t, result.Vulnerabilities, stored.Vulnerabilities)
+	assert.Equal(t, result.Errors, stored.Errors)
+}
+
+func TestSecurityScanAPI(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-jwt-secret-key-for-testing-only-32-chars")
+	Config.LoadConfig()
+	OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "测试", Version: "1.0.0"}))
+	t.Cleanup(func() {
+		OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "Cloud Platform API", Version: "1.0.0"}))
+	})
+
+	db := setupDB(t)
+	factory := Testing.NewFactory(t, db)
+	admin, user := factory.Admin(), factory.User()
+
+	blocking := &fakeScanner{name: "slow", vulns: []Services.Vulnerability{{Type: "x", Severity: "low"}}, started: make(chan struct{}), release: make(chan struct{})}
+	service := Services.NewSecurityAuditService(nil)
+	service.SetScanners([]Services.VulnerabilityScanner{blocking})
+
+	engine := gin.New()
+	Routes.RegisterSecurityScanRoutes(engine, Storage.NewStorageManager(&Config.StorageConfig{BasePath: t.TempDir()}),
+		Controllers.NewSecurityScanController(service))
+	request := func(token, method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+	adminToken := factory.Token(admin)
+
+	assert.Equal(t, http.StatusForbidden, request(factory.Token(user), http.MethodPost, "/api/v1/security/scans").Code)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- request(adminToken, http.MethodPost, "/api/v1/security/scans") }()
+	<-blocking.started
+	// 扫描执行期间再次触发返回409
+	assert.Equal(t, http.StatusConflict, request(adminToken, http.MethodPost, "/api/v1/security/scans").Code)
+	close(blocking.release)
+	w := <-done
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Data Models.SecurityScanResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, 1, created.Data.RiskScore)
+
+	w = request(adminToken, http.MethodGet, "/api/v1/security/scans?page=1&page_size=5")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), created.Data.ID)
+
+	w = request(adminToken, http.MethodGet, "/api/v1/security/scans/"+created.Data.ID)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"scanner":"slow"`)
+	assert.Equal(t, http.StatusNotFound, request(adminToken, http.MethodGet, "/api/v1/security/scans/missing").Code)
+}
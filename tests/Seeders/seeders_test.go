@@ -0,0 +1,162 @@
+package Seeders
+
+import (
+	"cloud-platform-api/app/Database/Seeders"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Testing"
+	"cloud-platform-api/app/Utils"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupSeedDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "seed.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(Seeders.Tables()...))
+	return db
+}
+
+func seedOptions() Seeders.Options {
+	return Seeders.Options{
+		AdminUsername: "root",
+		AdminEmail:    "root@example.com",
+		AdminPassword: Testing.DefaultPassword,
+		DemoPassword:  Testing.DefaultPassword,
+	}
+}
+
+func names(seeders []Seeders.Seeder) []string {
+	var result []string
+	for _, seeder := range seeders {
+		result = append(result, seeder.GetName())
+	}
+	return result
+}
+
+func TestSeedersForEnvironment(t *testing.T) {
+	assert.Equal(t, []string{"admin_user", "default_roles", "alert_rules"}, names(Seeders.ForEnvironment("production")))
+	assert.Equal(t, []string{"admin_user", "default_roles", "alert_rules"}, names(Seeders.ForEnvironment("Staging")))
+	assert.Equal(t, []string{"admin_user", "default_roles", "alert_rules", "demo_tenant"}, names(Seeders.ForEnvironment("development")))
+
+	selected, err := Seeders.Select(Seeders.ForEnvironment("development"), []string{"demo_tenant", " admin_user"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admin_user", "demo_tenant"}, names(selected), "保持依赖顺序")
+
+	_, err = Seeders.Select(Seeders.ForEnvironment("production"), []string{"demo_tenant"})
+	assert.Error(t, err, "生产环境不能填充演示数据")
+}
+
+func TestProductionSeedIsIdempotent(t *testing.T) {
+	db := setupSeedDB(t)
+	manager := Seeders.NewSeederManager(db)
+
+	report, err := manager.Run("production", Seeders.ForEnvironment("production"), seedOptions())
+	require.NoError(t, err)
+	require.Len(t, report.Credentials, 1)
+	assert.Equal(t, "root", report.Credentials[0].Username)
+
+	var admin Models.User
+	require.NoError(t, db.Where("username = ?", "root").First(&admin).Error)
+	assert.Equal(t, "admin", admin.Role)
+	assert.True(t, Utils.CheckPassword(Testing.DefaultPassword, admin.Password))
+
+	var member Models.TeamMember
+	require.NoError(t, db.Where("user_id = ?", admin.ID).First(&member).Error)
+	assert.Equal(t, Models.TeamRoleAdmin, member.Role)
+
+	var rules []Models.AlertRule
+	require.NoError(t, db.Find(&rules).Error)
+	assert.Len(t, rules, len(Seeders.SampleAlertRules()))
+	require.NotNil(t, rules[0].TeamID, "示例告警规则归属默认团队")
+	assert.Equal(t, member.TeamID, *rules[0].TeamID)
+
+	// 调整过的阈值不被覆盖，已存在的管理员不修改密码
+	require.NoError(t, db.Model(&Models.AlertRule{}).Where("name = ?", "cpu_usage_high").Update("threshold", 95).Error)
+	options := seedOptions()
+	options.AdminPassword = "Another-Passw0rd!"
+	report, err = manager.Run("production", Seeders.ForEnvironment("production"), options)
+	require.NoError(t, err)
+	assert.Empty(t, report.Credentials)
+	assert.Contains(t, report.Seeders[0].Notes[0], "已存在")
+
+	var count int64
+	db.Model(&Models.User{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+	db.Model(&Models.AlertRule{}).Count(&count)
+	assert.Equal(t, int64(len(Seeders.SampleAlertRules())), count)
+	var rule Models.AlertRule
+	require.NoError(t, db.Where("name = ?", "cpu_usage_high").First(&rule).Error)
+	assert.Equal(t, float64(95), rule.Threshold)
+	require.NoError(t, db.First(&admin, admin.ID).Error)
+	assert.True(t, Utils.CheckPassword(Testing.DefaultPassword, admin.Password))
+}
+
+func TestSeederFailureRollsBack(t *testing.T) {
+	db := setupSeedDB(t)
+	options := seedOptions()
+	options.AdminPassword = "weak"
+
+	report, err := Seeders.NewSeederManager(db).Run("production", Seeders.ForEnvironment("production"), options)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "admin_user")
+	assert.Empty(t, report.Credentials)
+
+	// 缺少管理员时后续填充器失败，不写入数据
+	_, err = Seeders.NewSeederManager(db).Run("production", Seeders.ForEnvironment("production")[1:], seedOptions())
+	require.Error(t, err)
+	var count int64
+	db.Model(&Models.Organization{}).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestDemoTenant(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "demo.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	report := Testing.SeedDemo(t, db)
+	assert.Equal(t, "demo", report.Environment)
+	assert.Len(t, report.Credentials, 4, "管理员和三个演示用户")
+
+	var team Models.Team
+	require.NoError(t, db.Where("name = ?", Seeders.DemoTeamName).First(&team).Error)
+	var members int64
+	db.Model(&Models.TeamMember{}).Where("team_id = ?", team.ID).Count(&members)
+	assert.Equal(t, int64(3), members)
+
+	var samples int64
+	db.Model(&Models.MetricSample{}).Where("name = ?", "cpu_usage").Count(&samples)
+	assert.Equal(t, int64(Seeders.DemoHistory/Seeders.DemoSampleInterval), samples)
+	var latest Models.MetricSample
+	require.NoError(t, db.Where("name = ?", "cpu_usage").Order("timestamp desc").First(&latest).Error)
+	assert.WithinDuration(t, time.Now(), latest.Timestamp, Seeders.DemoSampleInterval)
+
+	var active, resolved int64
+	db.Model(&Models.Alert{}).Where("status = ?", "active").Count(&active)
+	db.Model(&Models.Alert{}).Where("status = ?", "resolved").Count(&resolved)
+	assert.Equal(t, int64(1), active)
+	assert.Equal(t, int64(1), resolved)
+
+	var events, securityEvents int64
+	db.Model(&Models.MonitoringEvent{}).Count(&events)
+	db.Model(&Models.SecurityEvent{}).Where("event_type = ?", "login_failed").Count(&securityEvents)
+	assert.NotZero(t, events)
+	assert.Equal(t, int64(5), securityEvents)
+
+	// 重复执行不重复生成历史数据
+	report = Testing.SeedDemo(t, db)
+	assert.Empty(t, report.Credentials)
+	var again int64
+	db.Model(&Models.MetricSample{}).Where("name = ?", "cpu_usage").Count(&again)
+	assert.Equal(t, samples, again)
+}
@@ -0,0 +1,207 @@
+package Startup
+
+import (
+	"bufio"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis 只支持缓存服务用到的命令的Redis服务器，down 时直接断开连接
+type fakeRedis struct {
+	address string
+	down    atomic.Bool
+	mu      sync.Mutex
+	values  map[string]string
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	server := &fakeRedis{address: listener.Addr().String(), values: map[string]string{}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			if server.down.Load() {
+				conn.Close()
+				continue
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (s *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		io.WriteString(conn, s.handle(args))
+	}
+}
+
+// readCommand 读取一条RESP数组格式的命令
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, count)
+	for i := range args {
+		if line, err = reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
+
+func (s *fakeRedis) handle(args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "SET":
+		s.values[args[1]] = args[2]
+		return "+OK\r\n"
+	case "GET":
+		value, ok := s.values[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "DEL", "EXISTS":
+		_, ok := s.values[args[1]]
+		if ok && strings.ToUpper(args[0]) == "DEL" {
+			delete(s.values, args[1])
+		}
+		if ok {
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	case "KEYS":
+		reply := ""
+		count := 0
+		for key := range s.values {
+			if matched, _ := path.Match(args[1], key); matched {
+				reply += fmt.Sprintf("$%d\r\n%s\r\n", len(key), key)
+				count++
+			}
+		}
+		return fmt.Sprintf("*%d\r\n%s", count, reply)
+	default:
+		return "-ERR unknown command '" + args[0] + "'\r\n"
+	}
+}
+
+func (s *fakeRedis) get(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// TestCacheServiceSwitchesToRedisWhenRedisRecovers 降级启动后Redis恢复时缓存服务的读写改为经过Redis
+func TestCacheServiceSwitchesToRedisWhenRedisRecovers(t *testing.T) {
+	server := newFakeRedis(t)
+	server.down.Store(true)
+	host, portText, err := net.SplitHostPort(server.address)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(portText)
+	require.NoError(t, err)
+	redisService := Services.NewRedisService(&Services.RedisConfig{Host: host, Port: port})
+	t.Cleanup(func() { redisService.Close() })
+
+	cacheService := Services.NewCacheService(Storage.NewStorageManager(&Config.StorageConfig{BasePath: t.TempDir()}))
+	orchestrator := Services.NewStartupOrchestrator(startupConfig(func(config *Config.StartupConfig) {
+		config.Timeout = 20 * time.Millisecond
+		config.CriticalDependencies = "redis"
+		config.DegradedMode = true
+	}))
+	orchestrator.Register(Services.StartupDependency{
+		Name:     "redis",
+		Check:    redisService.PingContext,
+		Features: []string{"redis_cache"},
+		OnReady:  func() { cacheService.UseRedis(redisService) },
+	})
+	defer orchestrator.Stop()
+	require.NoError(t, orchestrator.Wait(context.Background()))
+
+	// Redis未就绪时使用内存缓存
+	assert.Equal(t, "memory", cacheService.Backend())
+	require.NoError(t, cacheService.Set("before", "memory"))
+	_, stored := server.get("cache:before")
+	assert.False(t, stored)
+
+	server.down.Store(false)
+	require.Eventually(t, func() bool { return orchestrator.DependencyReady("redis") }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "redis", cacheService.Backend())
+
+	// 切换后的读写都经过Redis
+	require.NoError(t, cacheService.Set("greeting", "hello", time.Minute))
+	value, stored := server.get("cache:greeting")
+	require.True(t, stored)
+	assert.Equal(t, `"hello"`, value)
+	cached, ok := cacheService.Get("greeting")
+	require.True(t, ok)
+	assert.Equal(t, "hello", cached)
+	_, ok = cacheService.Get("before")
+	assert.False(t, ok, "内存中的缓存项不迁移")
+
+	type profile struct {
+		Name string `json:"name"`
+	}
+	require.NoError(t, cacheService.SetWithJSON("profile", profile{Name: "alice"}))
+	var loaded profile
+	require.NoError(t, cacheService.GetWithJSON("profile", &loaded))
+	assert.Equal(t, "alice", loaded.Name)
+	assert.ElementsMatch(t, []string{"greeting", "profile"}, cacheService.Keys())
+	assert.True(t, cacheService.Exists("profile"))
+
+	cacheService.Delete("greeting")
+	_, stored = server.get("cache:greeting")
+	assert.False(t, stored)
+
+	// 清空缓存只删除缓存键前缀下的键
+	server.handle([]string{"SET", "session:1", "x"})
+	cacheService.Clear()
+	assert.Equal(t, 0, cacheService.Size())
+	_, stored = server.get("session:1")
+	assert.True(t, stored)
+}
@@ -0,0 +1,43 @@
+package Startup
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Routes"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRegisterRoutesDefersBackgroundServices 路由注册只登记后台服务，由应用启动时统一启动
+func TestRegisterRoutesDefersBackgroundServices(t *testing.T) {
+	// 认证中间件创建时读取配置
+	t.Setenv("JWT_SECRET", "test-jwt-secret-key-for-testing-only-32-chars")
+	t.Setenv("LOG_BASE_PATH", t.TempDir())
+	Config.LoadConfig()
+	t.Cleanup(func() {
+		OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "Cloud Platform API", Version: "1.0.0"}))
+	})
+
+	storageManager := Storage.NewStorageManager(&Config.StorageConfig{BasePath: t.TempDir()})
+	logManager := Services.NewLogManagerService(&Config.GetConfig().Log)
+
+	OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "测试", Version: "1.0.0"}))
+	background := Routes.RegisterRoutes(gin.New(), storageManager, logManager)
+	require.NotNil(t, background)
+
+	names := background.Names()
+	assert.Contains(t, names, "monitoring")
+	assert.Contains(t, names, "search")
+	assert.Contains(t, names, "profiling")
+	assert.Equal(t, "cleanup", names[len(names)-1], "清理调度器在全部清理任务注册后启动")
+
+	// 再次构建路由不会重复启动任何服务
+	OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "测试", Version: "1.0.0"}))
+	again := Routes.RegisterRoutes(gin.New(), storageManager, logManager)
+	assert.Equal(t, names, again.Names())
+}
@@ -0,0 +1,199 @@
+package Startup
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Services"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// startupConfig 测试用的短重试间隔配置
+func startupConfig(configure func(*Config.StartupConfig)) *Config.StartupConfig {
+	config := &Config.StartupConfig{
+		WaitEnabled:          true,
+		Timeout:              time.Second,
+		InitialBackoff:       time.Millisecond,
+		MaxBackoff:           5 * time.Millisecond,
+		CheckTimeout:         100 * time.Millisecond,
+		CriticalDependencies: "database",
+	}
+	if configure != nil {
+		configure(config)
+	}
+	return config
+}
+
+// flakyCheck 前 failures 次检查失败，之后成功；healthy 为 false 时一直失败
+type flakyCheck struct {
+	calls    int32
+	failures int32
+	healthy  atomic.Bool
+}
+
+func newFlakyCheck(failures int32) *flakyCheck {
+	check := &flakyCheck{failures: failures}
+	check.healthy.Store(true)
+	return check
+}
+
+func (f *flakyCheck) Check(ctx context.Context) error {
+	if atomic.AddInt32(&f.calls, 1) <= f.failures || !f.healthy.Load() {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func TestStartupWaitsForCriticalDependency(t *testing.T) {
+	database := newFlakyCheck(3)
+	orchestrator := Services.NewStartupOrchestrator(startupConfig(nil))
+	orchestrator.Register(Services.StartupDependency{Name: "database", Check: database.Check})
+	defer orchestrator.Stop()
+
+	require.NoError(t, orchestrator.Wait(context.Background()))
+	assert.Equal(t, Services.StartupStateReady, orchestrator.State())
+
+	statuses := orchestrator.Statuses()
+	require.Len(t, statuses, 1)
+	assert.True(t, statuses[0].Critical)
+	assert.Equal(t, Services.DependencyReady, statuses[0].State)
+	assert.Equal(t, 4, statuses[0].Attempts)
+	assert.Empty(t, statuses[0].LastError)
+	assert.NotNil(t, statuses[0].ReadyAt)
+	assert.NotEmpty(t, statuses[0].WaitDuration)
+}
+
+func TestStartupFailsWhenCriticalDependencyTimesOut(t *testing.T) {
+	database := newFlakyCheck(0)
+	database.healthy.Store(false)
+	cache := newFlakyCheck(0)
+	orchestrator := Services.NewStartupOrchestrator(startupConfig(func(config *Config.StartupConfig) {
+		config.Timeout = 50 * time.Millisecond
+	}))
+	orchestrator.Register(Services.StartupDependency{Name: "database", Check: database.Check})
+	orchestrator.Register(Services.StartupDependency{Name: "redis", Check: cache.Check})
+	defer orchestrator.Stop()
+
+	err := orchestrator.Wait(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "database")
+	assert.Contains(t, err.Error(), "connection refused")
+	assert.NotContains(t, err.Error(), "redis")
+	assert.Greater(t, atomic.LoadInt32(&database.calls), int32(1), "关键依赖按退避重试")
+}
+
+func TestStartupNonCriticalDependencyCheckedOnce(t *testing.T) {
+	cache := newFlakyCheck(0)
+	cache.healthy.Store(false)
+	orchestrator := Services.NewStartupOrchestrator(startupConfig(func(config *Config.StartupConfig) {
+		config.InitialBackoff = time.Hour
+		config.MaxBackoff = time.Hour
+	}))
+	orchestrator.Register(Services.StartupDependency{Name: "redis", Check: cache.Check, Features: []string{"redis_cache"}})
+	defer orchestrator.Stop()
+
+	require.NoError(t, orchestrator.Wait(context.Background()), "非关键依赖不可用不影响启动")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&cache.calls))
+	assert.Equal(t, Services.StartupStateDegraded, orchestrator.State())
+	assert.False(t, orchestrator.DependencyReady("redis"))
+	assert.True(t, orchestrator.DependencyReady("database"), "未注册的依赖视为就绪")
+	assert.Equal(t, []string{"redis_cache"}, orchestrator.DisabledFeatures())
+}
+
+func TestStartupDegradedModeRecovers(t *testing.T) {
+	database := newFlakyCheck(0)
+	database.healthy.Store(false)
+	var migrated atomic.Bool
+	orchestrator := Services.NewStartupOrchestrator(startupConfig(func(config *Config.StartupConfig) {
+		config.Timeout = 20 * time.Millisecond
+		config.DegradedMode = true
+	}))
+	orchestrator.Register(Services.StartupDependency{
+		Name:          "database",
+		Check:         database.Check,
+		Features:      []string{"api"},
+		RoutePrefixes: []string{"/api"},
+		OnReady:       func() { migrated.Store(true) },
+	})
+	defer orchestrator.Stop()
+	require.NoError(t, orchestrator.Wait(context.Background()))
+
+	health := Controllers.NewHealthController()
+	health.SetStartupOrchestrator(orchestrator)
+	router := gin.New()
+	router.Use(Middleware.NewStartupGateMiddleware(orchestrator).Handle())
+	router.GET("/readyz", health.DependencyReadiness)
+	router.GET("/api/v1/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/apidocs", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/users", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "database")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/apidocs", nil))
+	assert.Equal(t, http.StatusOK, w.Code, "只匹配完整的路径段")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, w.Code, "降级模式下继续接收流量")
+	var body struct {
+		Data struct {
+			Status           string                      `json:"status"`
+			Dependencies     []Services.DependencyStatus `json:"dependencies"`
+			DisabledFeatures []string                    `json:"disabled_features"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, Services.StartupStateDegraded, body.Data.Status)
+	assert.Equal(t, []string{"api"}, body.Data.DisabledFeatures)
+	require.Len(t, body.Data.Dependencies, 1)
+	assert.Equal(t, Services.DependencyUnavailable, body.Data.Dependencies[0].State)
+	assert.Equal(t, "connection refused", body.Data.Dependencies[0].LastError)
+
+	// 依赖恢复后执行 OnReady 并放行请求
+	database.healthy.Store(true)
+	require.Eventually(t, func() bool { return orchestrator.DependencyReady("database") }, time.Second, 5*time.Millisecond)
+	assert.True(t, migrated.Load())
+	assert.Equal(t, Services.StartupStateReady, orchestrator.State())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/users", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestStartupConfigValidate(t *testing.T) {
+	config := startupConfig(func(config *Config.StartupConfig) {
+		config.CriticalDependencies = "database, Redis"
+	})
+	assert.NoError(t, config.Validate())
+	assert.True(t, config.IsCritical("redis"))
+	assert.False(t, config.IsCritical("storage"))
+
+	for name, configure := range map[string]func(*Config.StartupConfig){
+		"timeout":       func(c *Config.StartupConfig) { c.Timeout = 0 },
+		"backoff":       func(c *Config.StartupConfig) { c.InitialBackoff = 0 },
+		"max_backoff":   func(c *Config.StartupConfig) { c.MaxBackoff = c.InitialBackoff / 2 },
+		"check_timeout": func(c *Config.StartupConfig) { c.CheckTimeout = 0 },
+	} {
+		invalid := *config
+		configure(&invalid)
+		assert.Error(t, invalid.Validate(), name)
+	}
+}
@@ -0,0 +1,200 @@
+package TLS
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Services"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCertificate 生成自签名证书写入文件，返回证书序列号
+func writeCertificate(t *testing.T, certFile, keyFile string, serial int64) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	// 先写入临时文件再重命名，模拟证书工具的原子替换
+	write := func(path string, block *pem.Block) {
+		tmp := path + ".tmp"
+		require.NoError(t, os.WriteFile(tmp, pem.EncodeToMemory(block), 0600))
+		require.NoError(t, os.Rename(tmp, path))
+	}
+	write(keyFile, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	write(certFile, &pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// certificateFiles 在临时目录生成证书，返回TLS配置
+func certificateFiles(t *testing.T) *Config.ServerTLSConfig {
+	dir := t.TempDir()
+	config := &Config.ServerTLSConfig{
+		Enabled:    true,
+		CertFile:   filepath.Join(dir, "tls.crt"),
+		KeyFile:    filepath.Join(dir, "tls.key"),
+		MinVersion: "1.2",
+		HTTP2:      true,
+		HTTPPort:   "80",
+	}
+	writeCertificate(t, config.CertFile, config.KeyFile, 1)
+	return config
+}
+
+func TestServerTLSConfigValidate(t *testing.T) {
+	valid := Config.ServerTLSConfig{Enabled: true, CertFile: "a.crt", KeyFile: "a.key", MinVersion: "1.2", CipherSuites: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", HTTPPort: "80"}
+	assert.NoError(t, valid.Validate())
+	assert.NoError(t, (&Config.ServerTLSConfig{}).Validate(), "未启用时不检查")
+
+	cases := map[string]func(c *Config.ServerTLSConfig){
+		"version":          func(c *Config.ServerTLSConfig) { c.MinVersion = "1.0" },
+		"insecure suite":   func(c *Config.ServerTLSConfig) { c.CipherSuites = "TLS_RSA_WITH_RC4_128_SHA" },
+		"suites with 1.3":  func(c *Config.ServerTLSConfig) { c.MinVersion = "1.3" },
+		"missing key":      func(c *Config.ServerTLSConfig) { c.KeyFile = "" },
+		"autocert domains": func(c *Config.ServerTLSConfig) { c.Autocert = true; c.AutocertCacheDir = "cache" },
+		"redirect port":    func(c *Config.ServerTLSConfig) { c.RedirectHTTP = true; c.HTTPPort = "" },
+	}
+	for name, mutate := range cases {
+		config := valid
+		mutate(&config)
+		assert.Error(t, config.Validate(), name)
+	}
+
+	autocert := Config.ServerTLSConfig{Enabled: true, Autocert: true, AutocertDomains: "api.example.com, www.example.com", AutocertCacheDir: "cache", HTTPPort: "80"}
+	assert.NoError(t, autocert.Validate())
+	assert.Equal(t, []string{"api.example.com", "www.example.com"}, autocert.DomainList())
+}
+
+func TestServerTLSNegotiatesHTTP2(t *testing.T) {
+	config := certificateFiles(t)
+	for _, http2 := range []bool{true, false} {
+		config.HTTP2 = http2
+		serverTLS, err := Services.NewServerTLS(config, "443")
+		require.NoError(t, err)
+		assert.Nil(t, serverTLS.HTTPHandler, "未启用重定向时不监听HTTP端口")
+
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(r.Proto))
+		}))
+		server.TLS = serverTLS.TLSConfig
+		server.EnableHTTP2 = http2
+		server.StartTLS()
+
+		transport := &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true, NextProtos: []string{"h2", "http/1.1"}},
+			ForceAttemptHTTP2: true,
+		}
+		resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http2, resp.ProtoMajor == 2, "http2=%v", http2)
+		assert.Equal(t, uint16(tls.VersionTLS12), serverTLS.TLSConfig.MinVersion)
+		server.Close()
+	}
+}
+
+func TestCertificateReloaderPicksUpReplacedFiles(t *testing.T) {
+	config := certificateFiles(t)
+	reloader, err := Services.NewCertificateReloader(config.CertFile, config.KeyFile)
+	require.NoError(t, err)
+	require.NoError(t, reloader.Watch())
+	defer reloader.Close()
+
+	cert, _ := reloader.GetCertificate(nil)
+	require.NotNil(t, cert.Leaf)
+	assert.Equal(t, int64(1), cert.Leaf.SerialNumber.Int64())
+
+	writeCertificate(t, config.CertFile, config.KeyFile, 2)
+	assert.Eventually(t, func() bool {
+		cert, _ := reloader.GetCertificate(nil)
+		return cert.Leaf.SerialNumber.Int64() == 2
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// 写入无效的证书时保留旧证书
+	require.NoError(t, os.WriteFile(config.CertFile, []byte("invalid"), 0600))
+	assert.Error(t, reloader.Reload())
+	cert, _ = reloader.GetCertificate(nil)
+	assert.Equal(t, int64(2), cert.Leaf.SerialNumber.Int64())
+}
+
+func TestHTTPSRedirect(t *testing.T) {
+	config := certificateFiles(t)
+	config.RedirectHTTP = true
+	serverTLS, err := Services.NewServerTLS(config, "8443")
+	require.NoError(t, err)
+	require.NotNil(t, serverTLS.HTTPHandler)
+
+	cases := []struct {
+		method   string
+		target   string
+		host     string
+		port     string
+		status   int
+		location string
+	}{
+		{http.MethodGet, "/api/v1/users?page=2", "api.example.com", "443", http.StatusMovedPermanently, "https://api.example.com/api/v1/users?page=2"},
+		{http.MethodGet, "/health", "api.example.com:80", "8443", http.StatusMovedPermanently, "https://api.example.com:8443/health"},
+		{http.MethodPost, "/api/v1/auth/login", "api.example.com", "443", http.StatusPermanentRedirect, "https://api.example.com/api/v1/auth/login"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.target, nil)
+		req.Host = tc.host
+		w := httptest.NewRecorder()
+		Services.HTTPSRedirectHandler(tc.port).ServeHTTP(w, req)
+		assert.Equal(t, tc.status, w.Code, tc.target)
+		assert.Equal(t, tc.location, w.Header().Get("Location"), tc.target)
+	}
+}
+
+func TestAutocertServesHTTP01Challenges(t *testing.T) {
+	config := &Config.ServerTLSConfig{
+		Enabled:          true,
+		Autocert:         true,
+		AutocertDomains:  "api.example.com",
+		AutocertCacheDir: t.TempDir(),
+		HTTP2:            true,
+		HTTPPort:         "80",
+	}
+	require.NoError(t, config.Validate())
+	serverTLS, err := Services.NewServerTLS(config, "443")
+	require.NoError(t, err)
+	assert.Nil(t, serverTLS.Reloader)
+	assert.Contains(t, serverTLS.TLSConfig.NextProtos, "acme-tls/1")
+	require.NotNil(t, serverTLS.HTTPHandler, "自动申请证书时总是监听HTTP端口")
+
+	// 没有进行中的验证时返回404；未启用重定向时其他请求也返回404
+	for _, target := range []string{"/.well-known/acme-challenge/unknown-token", "/api/v1/users"} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Host = "api.example.com"
+		w := httptest.NewRecorder()
+		serverTLS.HTTPHandler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code, target)
+	}
+}
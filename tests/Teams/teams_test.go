@@ -0,0 +1,315 @@
+package Teams
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Routes"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/OpenAPI"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Storage"
+	"cloud-platform-api/app/Testing"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func setupDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "teams.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&Models.User{}, &Models.Organization{}, &Models.Team{}, &Models.TeamMember{}, &Models.TeamInvitation{},
+		&Models.MonitoringDashboard{}, &Models.MonitoringWidget{}, &Models.AlertRule{},
+		&Models.ApiKey{}, &Models.ApiKeyUsage{}, &Models.AuditLog{},
+	))
+	previous := Database.DB
+	Database.DB = db
+	t.Cleanup(func() { Database.DB = previous })
+	return db
+}
+
+// fakeMailer 记录邀请邮件
+type fakeMailer struct {
+	to    []string
+	links []string
+}
+
+var acceptLink = regexp.MustCompile(`href="([^"]+)"`)
+
+func (m *fakeMailer) SendNotificationEmail(to, subject, body string) error {
+	m.to = append(m.to, to)
+	if match := acceptLink.FindStringSubmatch(body); match != nil {
+		m.links = append(m.links, match[1])
+	}
+	return nil
+}
+
+func newService(db *gorm.DB, mailer Services.NotificationMailer) *Services.TeamService {
+	return Services.NewTeamService(db, &Config.TeamsConfig{
+		InvitationTTL:     24 * time.Hour,
+		InvitationBaseURL: "https://app.example.com/invitations/accept",
+	}, mailer)
+}
+
+func actor(user *Models.User) Services.TeamActor {
+	return Services.TeamActor{UserID: user.ID, Role: user.Role}
+}
+
+func TestOrganizationsTeamsAndMembers(t *testing.T) {
+	db := setupDB(t)
+	factory := Testing.NewFactory(t, db)
+	owner, other := factory.User(), factory.User()
+	service := newService(db, nil)
+
+	organization, err := service.CreateOrganization("Acme", "", actor(owner))
+	require.NoError(t, err)
+	_, err = service.CreateOrganization(" Acme ", "", actor(other))
+	assert.ErrorIs(t, err, Services.ErrOrganizationNameExists)
+
+	// 只有组织所有者可以创建团队，创建者成为团队管理员
+	_, err = service.CreateTeam(organization.ID, "SRE", "", actor(other))
+	assert.ErrorIs(t, err, Services.ErrTeamForbidden)
+	team, err := service.CreateTeam(organization.ID, "SRE", "", actor(owner))
+	require.NoError(t, err)
+	_, err = service.CreateTeam(organization.ID, "SRE", "", actor(owner))
+	assert.ErrorIs(t, err, Services.ErrTeamNameExists)
+	assert.Equal(t, map[uint]string{team.ID: Models.TeamRoleAdmin}, service.Memberships(owner.ID))
+
+	// 非成员看不到团队，最后一名管理员不能退出或降级
+	_, err = service.GetTeam(team.ID, actor(other))
+	assert.ErrorIs(t, err, Services.ErrTeamNotFound)
+	teams, err := service.ListTeams(actor(other))
+	require.NoError(t, err)
+	assert.Empty(t, teams)
+	assert.ErrorIs(t, service.RemoveMember(team.ID, owner.ID, actor(owner)), Services.ErrLastTeamAdmin)
+	_, err = service.UpdateMemberRole(team.ID, owner.ID, Models.TeamRoleMember, actor(owner))
+	assert.ErrorIs(t, err, Services.ErrLastTeamAdmin)
+
+	require.NoError(t, db.Create(&Models.TeamMember{TeamID: team.ID, UserID: other.ID, Role: Models.TeamRoleMember}).Error)
+	detail, err := service.GetTeam(team.ID, actor(other))
+	require.NoError(t, err)
+	require.Len(t, detail.Members, 2)
+	assert.Equal(t, owner.Email, detail.Members[0].User.Email)
+	organizations, err := service.ListOrganizations(actor(other))
+	require.NoError(t, err)
+	assert.Len(t, organizations, 1)
+
+	// 普通成员不能管理成员，但可以退出团队
+	_, err = service.UpdateMemberRole(team.ID, owner.ID, Models.TeamRoleMember, actor(other))
+	assert.ErrorIs(t, err, Services.ErrTeamForbidden)
+	_, err = service.UpdateMemberRole(team.ID, other.ID, Models.TeamRoleAdmin, actor(owner))
+	require.NoError(t, err)
+	require.NoError(t, service.RemoveMember(team.ID, owner.ID, actor(owner)))
+	assert.Empty(t, service.Memberships(owner.ID))
+}
+
+func TestInvitationFlow(t *testing.T) {
+	db := setupDB(t)
+	factory := Testing.NewFactory(t, db)
+	owner, invitee, stranger := factory.User(), factory.User(), factory.User()
+	mailer := &fakeMailer{}
+	service := newService(db, mailer)
+	now := time.Now()
+	service.SetClock(func() time.Time { return now })
+
+	organization, err := service.CreateOrganization("Acme", "", actor(owner))
+	require.NoError(t, err)
+	team, err := service.CreateTeam(organization.ID, "SRE", "", actor(owner))
+	require.NoError(t, err)
+
+	_, _, err = service.Invite(team.ID, "not-an-email", "", actor(owner))
+	assert.ErrorIs(t, err, Services.ErrInvalidTeam)
+	_, _, err = service.Invite(team.ID, owner.Email, "", actor(owner))
+	assert.ErrorIs(t, err, Services.ErrTeamMemberExists)
+	_, _, err = service.Invite(team.ID, invitee.Email, "", actor(stranger))
+	assert.ErrorIs(t, err, Services.ErrTeamNotFound)
+
+	invitation, token, err := service.Invite(team.ID, invitee.Email, "", actor(owner))
+	require.NoError(t, err)
+	assert.Equal(t, Models.TeamRoleMember, invitation.Role)
+	assert.NotEqual(t, token, invitation.TokenHash, "只保存令牌哈希")
+	require.Equal(t, []string{invitee.Email}, mailer.to)
+	link, err := url.Parse(mailer.links[0])
+	require.NoError(t, err)
+	assert.Equal(t, "app.example.com", link.Host)
+	assert.Equal(t, token, link.Query().Get("token"))
+
+	// 邮箱不一致的用户不能接受，邀请只能使用一次
+	_, err = service.AcceptInvitation(token, stranger.ID)
+	assert.ErrorIs(t, err, Services.ErrInvitationEmailMismatch)
+	member, err := service.AcceptInvitation(token, invitee.ID)
+	require.NoError(t, err)
+	assert.Equal(t, team.ID, member.TeamID)
+	_, err = service.AcceptInvitation(token, invitee.ID)
+	assert.ErrorIs(t, err, Services.ErrInvitationNotFound)
+
+	// 过期的邀请不能接受
+	_, token, err = service.Invite(team.ID, stranger.Email, Models.TeamRoleAdmin, actor(owner))
+	require.NoError(t, err)
+	now = now.Add(25 * time.Hour)
+	_, err = service.AcceptInvitation(token, stranger.ID)
+	assert.ErrorIs(t, err, Services.ErrInvitationExpired)
+	invitations, err := service.ListInvitations(team.ID, actor(owner))
+	require.NoError(t, err)
+	assert.Empty(t, invitations)
+
+	// 撤销后令牌失效
+	invitation, token, err = service.Invite(team.ID, stranger.Email, "", actor(owner))
+	require.NoError(t, err)
+	require.NoError(t, service.RevokeInvitation(team.ID, invitation.ID, actor(owner)))
+	_, err = service.AcceptInvitation(token, stranger.ID)
+	assert.ErrorIs(t, err, Services.ErrInvitationNotFound)
+}
+
+func TestTeamOwnedResources(t *testing.T) {
+	db := setupDB(t)
+	factory := Testing.NewFactory(t, db)
+	owner, member, outsider := factory.User(), factory.User(), factory.User()
+	service := newService(db, nil)
+	Services.SetDefaultTeamService(service)
+	t.Cleanup(func() { Services.SetDefaultTeamService(nil) })
+
+	organization, err := service.CreateOrganization("Acme", "", actor(owner))
+	require.NoError(t, err)
+	team, err := service.CreateTeam(organization.ID, "SRE", "", actor(owner))
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&Models.TeamMember{TeamID: team.ID, UserID: member.ID, Role: Models.TeamRoleMember}).Error)
+
+	viewer := func(user *Models.User) Services.DashboardViewer {
+		return Services.DashboardViewer{UserID: user.ID, Role: user.Role, Teams: service.Memberships(user.ID)}
+	}
+
+	// 团队仪表板对团队成员可见可改，非成员不可见，不能归属到自己不在的团队
+	dashboards := Services.NewMonitoringDashboardService(db)
+	teamID := team.ID
+	dashboard, err := dashboards.CreateDashboard(Services.MonitoringDashboardInput{Name: "团队看板", TeamID: &teamID}, viewer(owner))
+	require.NoError(t, err)
+	_, err = dashboards.UpdateDashboard(dashboard.ID, Services.MonitoringDashboardInput{Name: "团队看板v2"}, viewer(member))
+	require.NoError(t, err)
+	_, err = dashboards.GetDashboard(dashboard.ID, viewer(outsider))
+	assert.ErrorIs(t, err, Services.ErrDashboardNotFound)
+	_, err = dashboards.CreateDashboard(Services.MonitoringDashboardInput{Name: "越权", TeamID: &teamID}, viewer(outsider))
+	assert.ErrorIs(t, err, Services.ErrDashboardForbidden)
+
+	// 团队告警规则只有团队成员可以修改和删除，经应用实际注册的路由访问
+	t.Setenv("JWT_SECRET", "test-jwt-secret-key-for-testing-only-32-chars")
+	t.Setenv("LOG_BASE_PATH", t.TempDir())
+	Config.LoadConfig()
+	t.Cleanup(func() {
+		OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "Cloud Platform API", Version: "1.0.0"}))
+	})
+	OpenAPI.SetDefaultRegistry(OpenAPI.NewRegistry(OpenAPI.Info{Title: "测试", Version: "1.0.0"}))
+	engine := gin.New()
+	Routes.RegisterRoutes(engine, Storage.NewStorageManager(&Config.StorageConfig{BasePath: t.TempDir()}), Services.NewLogManagerService(&Config.GetConfig().Log))
+	request := func(user *Models.User, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+factory.Token(user))
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+	rule := Models.AlertRule{Name: "cpu", Type: "threshold", MetricType: "system", MetricName: "cpu_usage", Condition: ">", Threshold: 80, Severity: "warning", Enabled: true, TeamID: &teamID, CreatedBy: owner.ID}
+	require.NoError(t, db.Create(&rule).Error)
+	path := "/api/v1/monitoring/alert-rules/" + strconv.FormatUint(uint64(rule.ID), 10)
+	assert.Equal(t, http.StatusForbidden, request(outsider, http.MethodPut, path, `{"threshold": 90}`).Code)
+	assert.Equal(t, http.StatusForbidden, request(outsider, http.MethodDelete, path, "").Code)
+	w := request(member, http.MethodPut, path, `{"threshold": 90}`)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusForbidden, request(outsider, http.MethodPut, path, `{"team_id": 0}`).Code)
+	created := `{"name": "mem", "type": "threshold", "metric_type": "system", "metric_name": "memory_usage", "condition": ">", "threshold": 90, "severity": "warning", "team_id": ` + strconv.FormatUint(uint64(teamID), 10) + `}`
+	assert.Equal(t, http.StatusForbidden, request(outsider, http.MethodPost, "/api/v1/monitoring/alert-rules", created).Code)
+	w = request(member, http.MethodPost, "/api/v1/monitoring/alert-rules", created)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var teamRules int64
+	require.NoError(t, db.Model(&Models.AlertRule{}).Where("team_id = ?", teamID).Count(&teamRules).Error)
+	assert.Equal(t, int64(2), teamRules)
+
+	// 团队API密钥对团队成员可见，团队成员可以删除
+	apiKeys := Services.NewApiKeyService()
+	_, _, err = apiKeys.CreateTeamApiKey(outsider.ID, team.ID, "deploy", nil, "", nil)
+	assert.ErrorIs(t, err, Services.ErrTeamForbidden)
+	apiKey, _, err := apiKeys.CreateTeamApiKey(owner.ID, team.ID, "deploy", nil, "", nil)
+	require.NoError(t, err)
+	keys, total, err := apiKeys.GetApiKeys(member.ID, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, apiKey.ID, keys[0].ID)
+	assert.Error(t, apiKeys.DeleteApiKey(outsider.ID, apiKey.ID))
+	require.NoError(t, apiKeys.DeleteApiKey(member.ID, apiKey.ID))
+}
+
+func TestTeamsAPI(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-jwt-secret-key-for-testing-only-32-chars")
+	Config.LoadConfig()
+	db := setupDB(t)
+	factory := Testing.NewFactory(t, db)
+	owner, invitee := factory.User(), factory.User()
+
+	engine := gin.New()
+	Routes.RegisterTeamRoutes(engine, Controllers.NewTeamController(newService(db, &fakeMailer{})))
+	request := func(user *Models.User, method, path, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+factory.Token(user))
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		var resp struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp.Data
+	}
+
+	w, organization := request(owner, http.MethodPost, "/api/v1/organizations", `{"name": "Acme"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	orgPath := "/api/v1/organizations/" + strconv.Itoa(int(organization["id"].(float64)))
+	w, _ = request(invitee, http.MethodPost, orgPath+"/teams", `{"name": "SRE"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w, team := request(owner, http.MethodPost, orgPath+"/teams", `{"name": "SRE"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	teamPath := "/api/v1/teams/" + strconv.Itoa(int(team["id"].(float64)))
+
+	w, _ = request(owner, http.MethodPost, teamPath+"/invitations", `{"email": "bad"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w, invitation := request(owner, http.MethodPost, teamPath+"/invitations", `{"email": "`+invitee.Email+`"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	token := invitation["token"].(string)
+
+	w, _ = request(owner, http.MethodPost, "/api/v1/teams/invitations/accept", `{"token": "`+token+`"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w, _ = request(invitee, http.MethodPost, "/api/v1/teams/invitations/accept", `{"token": "`+token+`"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w, _ = request(invitee, http.MethodPost, "/api/v1/teams/invitations/accept", `{"token": "`+token+`"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w, _ = request(invitee, http.MethodGet, teamPath, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w, _ = request(owner, http.MethodDelete, teamPath+"/members/"+strconv.FormatUint(uint64(owner.ID), 10), "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w, _ = request(invitee, http.MethodDelete, teamPath+"/members/"+strconv.FormatUint(uint64(invitee.ID), 10), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w, _ = request(invitee, http.MethodGet, teamPath, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
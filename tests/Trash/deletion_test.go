@@ -0,0 +1,128 @@
+package Trash
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupDeletionDB(t *testing.T) *gorm.DB {
+	db := setupDB(t)
+	require.NoError(t, db.AutoMigrate(&Models.Post{}, &Models.ApiKeyUsage{}, &Models.AccessControl{},
+		&Models.Notification{}, &Models.SecurityAlert{}, &Models.Alert{}))
+	return db
+}
+
+func createUser(t *testing.T, db *gorm.DB, username string) Models.User {
+	user := Models.User{Username: username, Email: username + "@example.com", Password: "x"}
+	require.NoError(t, db.Create(&user).Error)
+	return user
+}
+
+func count(t *testing.T, query *gorm.DB) int64 {
+	var n int64
+	require.NoError(t, query.Count(&n).Error)
+	return n
+}
+
+func TestDeleteUserCascadesIntoTrash(t *testing.T) {
+	db := setupDeletionDB(t)
+	service := Services.NewDeletionService(db, nil)
+	tokens := Services.NewTokenBlacklistService(nil)
+	service.SetTokenBlacklistService(tokens)
+
+	user := createUser(t, db, "alice")
+	post := Models.Post{Title: "hello", UserID: user.ID}
+	require.NoError(t, db.Create(&post).Error)
+	key := Models.ApiKey{UserID: user.ID, Name: "ci", KeyHash: "h", Prefix: "ak_1"}
+	require.NoError(t, db.Create(&key).Error)
+	oldKey := Models.ApiKey{UserID: user.ID, Name: "old", KeyHash: "h", Prefix: "ak_2"}
+	require.NoError(t, db.Create(&oldKey).Error)
+	require.NoError(t, db.Create(&Models.ApiKeyUsage{ApiKeyID: key.ID, UserID: user.ID, StatusCode: 200}).Error)
+	require.NoError(t, db.Create(&Models.Notification{UserID: user.ID, Type: "system", Title: "hi"}).Error)
+
+	// 有未删除的文章时拒绝删除
+	assert.ErrorIs(t, service.Delete(Services.TrashUsers, user.ID), Services.ErrDeletionRestricted)
+	require.NoError(t, db.Delete(&post).Error)
+
+	// 先单独删除的密钥不随用户恢复
+	service.SetClock(func() time.Time { return time.Now().Add(-time.Hour) })
+	require.NoError(t, service.Delete(Services.TrashApiKeys, oldKey.ID))
+	service.SetClock(time.Now)
+	require.NoError(t, service.Delete(Services.TrashUsers, user.ID))
+	assert.ErrorIs(t, service.Delete(Services.TrashUsers, user.ID), Services.ErrDeletionNotFound)
+	assert.True(t, tokens.IsUserTokenRevoked(user.ID, time.Now().Add(-time.Minute)))
+
+	assert.Zero(t, count(t, db.Model(&Models.ApiKey{})))
+	assert.Zero(t, count(t, db.Model(&Models.ApiKeyUsage{})))
+	assert.Equal(t, int64(1), count(t, db.Model(&Models.Notification{})), "不支持软删除的关联数据在彻底删除时才删除")
+
+	// 用户在回收站中时不能单独恢复其API密钥
+	assert.ErrorIs(t, service.Restore(Services.TrashApiKeys, key.ID), Services.ErrDeletionParentDeleted)
+	require.NoError(t, service.Restore(Services.TrashUsers, user.ID))
+	var keys []string
+	require.NoError(t, db.Model(&Models.ApiKey{}).Pluck("name", &keys).Error)
+	assert.Equal(t, []string{"ci"}, keys)
+	assert.Equal(t, int64(1), count(t, db.Model(&Models.ApiKeyUsage{})))
+	assert.ErrorIs(t, service.Restore(Services.TrashUsers, user.ID), Services.ErrTrashItemNotFound)
+}
+
+func TestForceDeleteAppliesPoliciesInBatches(t *testing.T) {
+	db := setupDeletionDB(t)
+	service := Services.NewDeletionService(db, &Config.TrashConfig{PurgeBatch: 2})
+
+	user := createUser(t, db, "bob")
+	other := createUser(t, db, "carol")
+	post := Models.Post{Title: "hello", UserID: user.ID}
+	require.NoError(t, db.Create(&post).Error)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, db.Create(&Models.Notification{UserID: user.ID, Type: "system", Title: "hi"}).Error)
+	}
+	require.NoError(t, db.Create(&Models.Notification{UserID: other.ID, Type: "system", Title: "hi"}).Error)
+	alert := Models.SecurityAlert{UserID: &user.ID, AcknowledgedBy: &user.ID, AlertType: "login", Severity: "high", Title: "t"}
+	require.NoError(t, db.Create(&alert).Error)
+
+	// 未删除的文章阻止彻底删除，回收站中的文章一并彻底删除
+	assert.ErrorIs(t, service.ForceDelete(Services.TrashUsers, []uint{user.ID}), Services.ErrDeletionRestricted)
+	require.NoError(t, db.Delete(&post).Error)
+	require.NoError(t, service.ForceDelete(Services.TrashUsers, []uint{user.ID}))
+
+	assert.Zero(t, count(t, db.Unscoped().Model(&Models.User{}).Where("id = ?", user.ID)))
+	assert.Zero(t, count(t, db.Unscoped().Model(&Models.Post{})))
+	assert.Equal(t, int64(1), count(t, db.Model(&Models.Notification{})), "其他用户的通知不受影响")
+	var stored Models.SecurityAlert
+	require.NoError(t, db.First(&stored, alert.ID).Error)
+	assert.Nil(t, stored.UserID)
+	assert.Nil(t, stored.AcknowledgedBy)
+}
+
+func TestIntegrityCollectorReportsOrphans(t *testing.T) {
+	db := setupDeletionDB(t)
+	service := Services.NewDeletionService(db, nil)
+
+	user := createUser(t, db, "dave")
+	require.NoError(t, db.Create(&Models.Notification{UserID: user.ID, Type: "system", Title: "ok"}).Error)
+	require.NoError(t, db.Create(&Models.Notification{UserID: 999, Type: "system", Title: "orphan"}).Error)
+	require.NoError(t, db.Create(&Models.ApiKey{UserID: 998, Name: "orphan", KeyHash: "h", Prefix: "ak_3"}).Error)
+
+	// 回收站中的用户仍然存在，引用它的记录不是孤儿数据
+	trashed := createUser(t, db, "erin")
+	require.NoError(t, db.Create(&Models.Notification{UserID: trashed.ID, Type: "system", Title: "trashed"}).Error)
+	require.NoError(t, service.Delete(Services.TrashUsers, trashed.ID))
+
+	values, err := service.IntegrityCollector().Collect(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, values["data_integrity_orphans_total"])
+	assert.Equal(t, 1.0, values["data_integrity_orphans_notifications_user_id"])
+	assert.Equal(t, 1.0, values["data_integrity_orphans_api_keys_user_id"])
+	assert.Equal(t, 0.0, values["data_integrity_orphans_monitoring_widgets_dashboard_id"])
+	_, ok := values["data_integrity_orphans_password_history_user_id"]
+	assert.False(t, ok, "没有创建的表不检查")
+}
@@ -0,0 +1,177 @@
+package Trash
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func setupDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "trash.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.User{}, &Models.ApiKey{}, &Models.AlertRule{},
+		&Models.MonitoringDashboard{}, &Models.MonitoringWidget{}))
+	previous := Database.DB
+	Database.DB = db
+	t.Cleanup(func() { Database.DB = previous })
+	return db
+}
+
+func createRule(t *testing.T, db *gorm.DB, name string) Models.AlertRule {
+	rule := Models.AlertRule{Name: name, Type: "threshold", MetricType: "system", MetricName: "cpu_usage", Condition: ">", Threshold: 80, Severity: "warning"}
+	require.NoError(t, db.Create(&rule).Error)
+	return rule
+}
+
+func newEngine(service *Services.TrashService) *gin.Engine {
+	controller := Controllers.NewTrashController(service)
+	engine := gin.New()
+	engine.GET("/trash/:type", controller.GetTrash)
+	engine.POST("/trash/:type/:id/restore", controller.RestoreTrash)
+	engine.DELETE("/trash/:type/:id", controller.ForceDeleteTrash)
+	return engine
+}
+
+func do(engine *gin.Engine, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func TestDeleteAlertRuleMovesToTrash(t *testing.T) {
+	db := setupDB(t)
+	rule := createRule(t, db, "cpu")
+	engine := gin.New()
+	engine.DELETE("/alert-rules/:id", Controllers.NewMonitoringController().DeleteAlertRule)
+	path := "/alert-rules/" + strconv.Itoa(int(rule.ID))
+
+	require.Equal(t, http.StatusOK, do(engine, http.MethodDelete, path).Code)
+	assert.Equal(t, http.StatusNotFound, do(engine, http.MethodDelete, path).Code, "已删除的规则不能重复删除")
+	assert.ErrorIs(t, db.First(&Models.AlertRule{}, rule.ID).Error, gorm.ErrRecordNotFound)
+
+	// 回收站中可以看到并恢复
+	trash := newEngine(Services.NewTrashService(db, nil))
+	w := do(trash, http.MethodGet, "/trash/alert-rules")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Data []Models.AlertRule `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, "cpu", resp.Data[0].Name)
+	assert.True(t, resp.Data[0].DeletedAt.Valid)
+
+	require.Equal(t, http.StatusOK, do(trash, http.MethodPost, "/trash/alert-rules/"+strconv.Itoa(int(rule.ID))+"/restore").Code)
+	require.NoError(t, db.First(&Models.AlertRule{}, rule.ID).Error)
+	assert.Equal(t, http.StatusNotFound, do(trash, http.MethodPost, "/trash/alert-rules/"+strconv.Itoa(int(rule.ID))+"/restore").Code, "未删除的记录不在回收站中")
+}
+
+func TestTrashEndpointErrors(t *testing.T) {
+	db := setupDB(t)
+	engine := newEngine(Services.NewTrashService(db, nil))
+
+	assert.Equal(t, http.StatusNotFound, do(engine, http.MethodGet, "/trash/widgets").Code)
+	assert.Equal(t, http.StatusBadRequest, do(engine, http.MethodPost, "/trash/users/abc/restore").Code)
+	assert.Equal(t, http.StatusNotFound, do(engine, http.MethodDelete, "/trash/users/42").Code)
+	assert.Equal(t, []string{"alert-rules", "api-keys", "dashboards", "users"}, Services.NewTrashService(db, nil).Types())
+}
+
+func TestForceDeleteCascades(t *testing.T) {
+	db := setupDB(t)
+	service := Services.NewTrashService(db, nil)
+
+	user := Models.User{Username: "alice", Email: "alice@example.com", Password: "x"}
+	require.NoError(t, db.Create(&user).Error)
+	require.NoError(t, db.Create(&Models.ApiKey{UserID: user.ID, Name: "ci", KeyHash: "h", Prefix: "ak_1"}).Error)
+
+	// 未删除的记录不能彻底删除
+	assert.ErrorIs(t, service.ForceDelete(Services.TrashUsers, user.ID), Services.ErrTrashItemNotFound)
+	require.NoError(t, db.Delete(&user).Error)
+	require.NoError(t, service.ForceDelete(Services.TrashUsers, user.ID))
+
+	var count int64
+	require.NoError(t, db.Unscoped().Model(&Models.User{}).Count(&count).Error)
+	assert.Zero(t, count)
+	require.NoError(t, db.Unscoped().Model(&Models.ApiKey{}).Count(&count).Error)
+	assert.Zero(t, count, "彻底删除用户时删除其API密钥")
+
+	// 仪表板在回收站中保留组件，彻底删除时一并删除
+	dashboards := Services.NewMonitoringDashboardService(db)
+	owner := Services.DashboardViewer{UserID: 1, Role: "user"}
+	dashboard, err := dashboards.CreateDashboard(Services.MonitoringDashboardInput{Name: "总览"}, owner)
+	require.NoError(t, err)
+	_, err = dashboards.CreateWidget(dashboard.ID, Services.MonitoringWidgetInput{Name: "说明", Type: "text"}, owner)
+	require.NoError(t, err)
+	require.NoError(t, dashboards.DeleteDashboard(dashboard.ID, owner))
+	require.NoError(t, db.Model(&Models.MonitoringWidget{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	// 回收站中的仪表板仍占用名称
+	_, err = dashboards.CreateDashboard(Services.MonitoringDashboardInput{Name: "总览"}, owner)
+	assert.ErrorIs(t, err, Services.ErrDashboardNameExists)
+
+	require.NoError(t, service.ForceDelete(Services.TrashDashboards, dashboard.ID))
+	require.NoError(t, db.Model(&Models.MonitoringWidget{}).Count(&count).Error)
+	assert.Zero(t, count)
+	_, err = dashboards.CreateDashboard(Services.MonitoringDashboardInput{Name: "总览"}, owner)
+	assert.NoError(t, err)
+}
+
+func TestPurgeExpiredItems(t *testing.T) {
+	db := setupDB(t)
+	service := Services.NewTrashService(db, &Config.TrashConfig{Retention: 24 * time.Hour, PurgeInterval: time.Hour, PurgeBatch: 1})
+	now := time.Now()
+
+	expired := []Models.AlertRule{createRule(t, db, "old-1"), createRule(t, db, "old-2")}
+	recent := createRule(t, db, "recent")
+	for _, rule := range expired {
+		require.NoError(t, db.Model(&Models.AlertRule{}).Where("id = ?", rule.ID).Update("deleted_at", now.Add(-48*time.Hour)).Error)
+	}
+	require.NoError(t, db.Delete(&recent).Error)
+
+	// 每次最多清理 PurgeBatch 条
+	service.SetClock(func() time.Time { return now })
+	purged, err := service.Purge(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{Services.TrashAlertRules: 1}, purged)
+	purged, err = service.Purge(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{Services.TrashAlertRules: 1}, purged)
+	purged, err = service.Purge(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, purged)
+
+	var names []string
+	require.NoError(t, db.Unscoped().Model(&Models.AlertRule{}).Pluck("name", &names).Error)
+	assert.Equal(t, []string{"recent"}, names)
+
+	// 保留时间为0时不清理
+	service = Services.NewTrashService(db, &Config.TrashConfig{Retention: 0, PurgeBatch: 1})
+	service.SetClock(func() time.Time { return now.Add(365 * 24 * time.Hour) })
+	purged, err = service.Purge(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, purged)
+}
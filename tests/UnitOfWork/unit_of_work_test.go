@@ -0,0 +1,186 @@
+package UnitOfWork
+
+import (
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type uowRecord struct {
+	ID   uint
+	Name string
+}
+
+func setupDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "uow.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&uowRecord{}))
+	return db
+}
+
+func names(t *testing.T, db *gorm.DB) []string {
+	var result []string
+	require.NoError(t, db.Model(&uowRecord{}).Order("id").Pluck("name", &result).Error)
+	return result
+}
+
+func TestUnitOfWorkCommitAndRollback(t *testing.T) {
+	db := setupDB(t)
+	uow := Database.NewUnitOfWork(db)
+
+	require.NoError(t, uow.WithTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+		return tx.Create(&uowRecord{Name: "committed"}).Error
+	}))
+
+	failure := errors.New("业务失败")
+	err := uow.WithTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+		require.NoError(t, tx.Create(&uowRecord{Name: "rolled back"}).Error)
+		return failure
+	})
+	assert.ErrorIs(t, err, failure)
+
+	assert.Panics(t, func() {
+		uow.WithTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+			require.NoError(t, tx.Create(&uowRecord{Name: "panicked"}).Error)
+			panic("boom")
+		})
+	})
+	assert.Equal(t, []string{"committed"}, names(t, db))
+}
+
+func TestUnitOfWorkNestedSavepoints(t *testing.T) {
+	db := setupDB(t)
+	uow := Database.NewUnitOfWork(db)
+	// 另一个服务持有自己的工作单元，通过 ctx 加入同一个事务
+	other := Database.NewUnitOfWork(db)
+
+	err := uow.WithTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+		require.NoError(t, tx.Create(&uowRecord{Name: "outer"}).Error)
+
+		current, ok := Database.TxFromContext(ctx)
+		require.True(t, ok)
+		assert.Same(t, tx, current)
+		assert.Same(t, tx, other.DB(ctx))
+
+		// 内层失败只回滚到保存点
+		inner := other.WithTransaction(ctx, func(ctx context.Context, tx *gorm.DB) error {
+			require.NoError(t, tx.Create(&uowRecord{Name: "inner failed"}).Error)
+			return errors.New("内层失败")
+		})
+		assert.Error(t, inner)
+
+		return other.WithTransaction(ctx, func(ctx context.Context, tx *gorm.DB) error {
+			return tx.Create(&uowRecord{Name: "inner committed"}).Error
+		})
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"outer", "inner committed"}, names(t, db))
+
+	// 外层回滚时内层已提交的保存点一起回滚
+	err = uow.WithTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+		if err := uow.WithTransaction(ctx, func(ctx context.Context, tx *gorm.DB) error {
+			return tx.Create(&uowRecord{Name: "discarded"}).Error
+		}); err != nil {
+			return err
+		}
+		return errors.New("外层失败")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"outer", "inner committed"}, names(t, db))
+
+	_, ok := Database.TxFromContext(context.Background())
+	assert.False(t, ok)
+}
+
+func TestUnitOfWorkRetriesSerializationFailures(t *testing.T) {
+	db := setupDB(t)
+	uow := Database.NewUnitOfWork(db)
+	uow.SetRetry(3, time.Millisecond)
+
+	attempts := 0
+	err := uow.WithTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+		attempts++
+		if err := tx.Create(&uowRecord{Name: fmt.Sprintf("attempt %d", attempts)}).Error; err != nil {
+			return err
+		}
+		if attempts < 3 {
+			return errors.New("ERROR: could not serialize access due to concurrent update (SQLSTATE 40001)")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, []string{"attempt 3"}, names(t, db), "失败的尝试全部回滚")
+
+	// 超过重试次数后返回最后一次的错误
+	uow.SetRetry(1, time.Millisecond)
+	attempts = 0
+	locked := errors.New("database is locked (5) (SQLITE_BUSY)")
+	err = uow.WithTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+		attempts++
+		return locked
+	})
+	assert.ErrorIs(t, err, locked)
+	assert.Equal(t, 2, attempts)
+
+	// 其他错误和内层事务不重试
+	attempts = 0
+	err = uow.WithTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+		return uow.WithTransaction(ctx, func(ctx context.Context, tx *gorm.DB) error {
+			attempts++
+			return errors.New("UNIQUE constraint failed")
+		})
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+
+	assert.True(t, Database.IsSerializationFailure(errors.New("Error 1213 (40001): Deadlock found when trying to get lock")))
+	assert.True(t, Database.IsSerializationFailure(fmt.Errorf("保存失败: %w", errors.New("ERROR: deadlock detected (SQLSTATE 40P01)"))))
+	assert.False(t, Database.IsSerializationFailure(context.DeadlineExceeded))
+	assert.False(t, Database.IsSerializationFailure(nil))
+}
+
+func TestRecordPasswordChangeTrimsHistoryInTransaction(t *testing.T) {
+	db := setupDB(t)
+	require.NoError(t, db.AutoMigrate(&Models.PasswordHistory{}))
+	config := &Config.SecurityConfig{}
+	config.SetDefaults()
+	config.BaseSecurity.PasswordHistoryCount = 2
+	service := Services.NewSecurityService(db, config)
+	defer service.Close()
+
+	for i := 1; i <= 4; i++ {
+		require.NoError(t, service.RecordPasswordChange(context.Background(), 7, 7, fmt.Sprintf("hash-%d", i), "change", "127.0.0.1", "test"))
+	}
+	var hashes []string
+	require.NoError(t, db.Model(&Models.PasswordHistory{}).Where("user_id = ?", 7).Order("id").Pluck("password_hash", &hashes).Error)
+	assert.Equal(t, []string{"hash-3", "hash-4"}, hashes)
+
+	// 在调用方的事务中记录，调用方回滚时密码历史一起回滚
+	uow := Database.NewUnitOfWork(db)
+	err := uow.WithTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+		if err := service.RecordPasswordChange(ctx, 7, 7, "hash-5", "change", "127.0.0.1", "test"); err != nil {
+			return err
+		}
+		return errors.New("更新用户失败")
+	})
+	assert.Error(t, err)
+	hashes = nil
+	require.NoError(t, db.Model(&Models.PasswordHistory{}).Where("user_id = ?", 7).Order("id").Pluck("password_hash", &hashes).Error)
+	assert.Equal(t, []string{"hash-3", "hash-4"}, hashes)
+}
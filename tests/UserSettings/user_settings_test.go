@@ -0,0 +1,216 @@
+package UserSettings
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Database"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Http/Middleware"
+	"cloud-platform-api/app/Http/Routes"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Testing"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func setupDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "settings.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.User{}, &Models.UserSetting{}, &Models.Notification{}))
+	previous := Database.DB
+	Database.DB = db
+	t.Cleanup(func() { Database.DB = previous })
+	return db
+}
+
+func newService(db *gorm.DB) *Services.UserSettingsService {
+	return Services.NewUserSettingsService(db, &Config.UserSettingsConfig{
+		NotifyInApp: true, NotifyEmail: true, NotifySMS: false,
+		Timezone: "Asia/Shanghai", DashboardTimeRange: "24h", DashboardRefreshSeconds: 60,
+	}, &Config.I18nConfig{DefaultLocale: "zh-CN", Supported: []string{"zh-CN", "en"}})
+}
+
+// fakeMailer 记录收件人
+type fakeMailer struct {
+	sent []string
+}
+
+func (m *fakeMailer) SendNotificationEmail(to, subject, body string) error {
+	m.sent = append(m.sent, to)
+	return nil
+}
+
+func TestSettingsDefaultsUpdateAndReset(t *testing.T) {
+	db := setupDB(t)
+	user := Testing.NewFactory(t, db).User()
+	service := newService(db)
+
+	settings, err := service.Get(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, true, settings[Services.SettingNotifyEmail])
+	assert.Equal(t, false, settings[Services.SettingNotifySMS])
+	assert.Equal(t, "zh-CN", settings[Services.SettingLocale])
+	assert.Equal(t, "Asia/Shanghai", settings[Services.SettingTimezone])
+	assert.Equal(t, 60, settings[Services.SettingDashboardRefreshSeconds])
+
+	settings, err = service.Update(user.ID, map[string]interface{}{
+		Services.SettingNotifySMS:               true,
+		Services.SettingLocale:                  "en-us",
+		Services.SettingTimezone:                "Europe/Berlin",
+		Services.SettingDashboardRefreshSeconds: float64(300),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, true, settings[Services.SettingNotifySMS])
+	assert.Equal(t, "en", settings[Services.SettingLocale])
+	assert.Equal(t, "Europe/Berlin", settings[Services.SettingTimezone])
+	assert.Equal(t, 300, settings[Services.SettingDashboardRefreshSeconds])
+
+	// 语言保存在 users.locale，与请求语言解析一致
+	assert.Equal(t, "en", Services.NewUserService().GetPreferredLocale(strconv.FormatUint(uint64(user.ID), 10)))
+
+	// 再次修改同一设置项时覆盖原值
+	_, err = service.Update(user.ID, map[string]interface{}{Services.SettingNotifySMS: false})
+	require.NoError(t, err)
+	var count int64
+	db.Model(&Models.UserSetting{}).Where("user_id = ? AND `key` = ?", user.ID, Services.SettingNotifySMS).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	// 任一项无效时都不保存
+	invalid := []map[string]interface{}{
+		{Services.SettingNotifyEmail: "no"},
+		{Services.SettingTimezone: "Mars/Olympus"},
+		{Services.SettingLocale: "fr"},
+		{Services.SettingDashboardTimeRange: "2h"},
+		{Services.SettingDashboardRefreshSeconds: float64(7200)},
+		{Services.SettingDashboardRefreshSeconds: 1.5},
+		{Services.SettingNotifyEmail: false, Services.SettingDashboardDefault: float64(-1)},
+	}
+	for _, values := range invalid {
+		_, err := service.Update(user.ID, values)
+		assert.ErrorIs(t, err, Services.ErrInvalidSetting, values)
+	}
+	_, err = service.Update(user.ID, map[string]interface{}{"theme": "dark"})
+	assert.ErrorIs(t, err, Services.ErrUnknownSetting)
+
+	settings, err = service.Get(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, true, settings[Services.SettingNotifyEmail])
+
+	settings, err = service.Reset(user.ID, Services.SettingTimezone)
+	require.NoError(t, err)
+	assert.Equal(t, "Asia/Shanghai", settings[Services.SettingTimezone])
+	settings, err = service.Reset(user.ID, Services.SettingLocale)
+	require.NoError(t, err)
+	assert.Equal(t, "zh-CN", settings[Services.SettingLocale])
+}
+
+func TestDispatcherSkipsOptedOutChannels(t *testing.T) {
+	db := setupDB(t)
+	user := Testing.NewFactory(t, db).User()
+	Services.SetDefaultUserSettingsService(newService(db))
+	t.Cleanup(func() { Services.SetDefaultUserSettingsService(nil) })
+
+	mailer := &fakeMailer{}
+	notifications := Services.NewNotificationService(db, nil)
+	dispatcher := Services.NewUserNotificationDispatcher(db, mailer)
+	dispatcher.SetNotificationService(notifications)
+	notification := Services.UserNotification{
+		Type: Models.NotificationTypeSystem, Title: "提醒", Content: "内容",
+		EmailSubject: "提醒", EmailBody: "<p>内容</p>",
+		SMSTemplate: Services.SMSTemplatePasswordExpiring, SMSVars: map[string]string{"expires_at": "明天"},
+	}
+
+	// 用户没有手机号，短信渠道不可用
+	result := dispatcher.Dispatch(context.Background(), user, notification)
+	assert.ElementsMatch(t, []string{Services.NotificationChannelInApp, Services.NotificationChannelEmail}, result.Delivered)
+	assert.Empty(t, result.OptedOut)
+	assert.Equal(t, []string{user.Email}, mailer.sent)
+
+	_, err := Services.DefaultUserSettingsService(db).Update(user.ID, map[string]interface{}{
+		Services.SettingNotifyEmail: false,
+		Services.SettingNotifyInApp: false,
+	})
+	require.NoError(t, err)
+	result = dispatcher.Dispatch(context.Background(), user, notification)
+	assert.Empty(t, result.Delivered)
+	assert.ElementsMatch(t, []string{Services.NotificationChannelInApp, Services.NotificationChannelEmail}, result.OptedOut)
+	assert.Len(t, mailer.sent, 1)
+
+	_, total, err := notifications.List(user.ID, Services.NotificationFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+}
+
+func TestSettingsAPI(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-jwt-secret-key-for-testing-only-32-chars")
+	Config.LoadConfig()
+	db := setupDB(t)
+	factory := Testing.NewFactory(t, db)
+	user := factory.User()
+
+	engine := gin.New()
+	userGroup := engine.Group("/api/v1/users", Middleware.NewAuthMiddleware().Handle())
+	userGroup.GET("/:id", func(c *gin.Context) { c.String(http.StatusOK, c.Param("id")) })
+	Routes.RegisterUserSettingsRoutes(engine, Controllers.NewUserSettingsController(newService(db)))
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/users/me/settings"+path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+factory.Token(user))
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+	type response struct {
+		Data struct {
+			Settings    map[string]interface{}       `json:"settings"`
+			Definitions []Services.SettingDefinition `json:"definitions"`
+		} `json:"data"`
+	}
+	decode := func(w *httptest.ResponseRecorder) response {
+		var resp response
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	w := request(http.MethodGet, "", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	resp := decode(w)
+	assert.Len(t, resp.Data.Definitions, len(newService(db).Definitions()))
+	assert.Equal(t, "24h", resp.Data.Settings[Services.SettingDashboardTimeRange])
+
+	w = request(http.MethodPatch, "", `{"notifications.sms": true, "dashboard.time_range": "7d"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "7d", decode(w).Data.Settings[Services.SettingDashboardTimeRange])
+
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPatch, "", `{"dashboard.time_range": "2h"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPatch, "", `{"theme": "dark"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPatch, "", `{}`).Code)
+
+	w = request(http.MethodDelete, "/dashboard.time_range", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "24h", decode(w).Data.Settings[Services.SettingDashboardTimeRange])
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodDelete, "/theme", "").Code)
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/users/me/settings", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
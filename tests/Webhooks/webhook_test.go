@@ -0,0 +1,418 @@
+package Webhooks
+
+import (
+	"bytes"
+	"cloud-platform-api/app/Config"
+	"cloud-platform-api/app/Http/Controllers"
+	"cloud-platform-api/app/Models"
+	"cloud-platform-api/app/Services"
+	"cloud-platform-api/app/Utils"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupWebhookDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "webhooks.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Models.OutboxEvent{}, &Models.WebhookSubscription{}, &Models.WebhookDelivery{}))
+	return db
+}
+
+// newWebhookService 创建允许投递到本机（httptest）的Webhook服务
+func newWebhookService(db *gorm.DB) *Services.WebhookService {
+	return Services.NewWebhookService(db, &Config.WebhookConfig{
+		Enabled:              true,
+		Workers:              1,
+		QueueSize:            10,
+		Timeout:              2 * time.Second,
+		MaxAttempts:          3,
+		RetryBackoff:         time.Minute,
+		MaxBackoff:           time.Hour,
+		AllowPrivateNetworks: true,
+	})
+}
+
+// receiver 记录收到的Webhook请求，按 statuses 依次返回状态码
+type receiver struct {
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+	statuses []int
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+	io.WriteString(w, `{"received":true}`)
+}
+
+func (r *receiver) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.requests)
+}
+
+func domainEvent(eventType string, payload string) Services.DomainEvent {
+	return Services.DomainEvent{
+		ID:          "evt-" + eventType,
+		Type:        eventType,
+		AggregateID: "1",
+		Payload:     json.RawMessage(payload),
+		OccurredAt:  time.Now(),
+	}
+}
+
+func deliveriesOf(t *testing.T, db *gorm.DB, subscriptionID uint) []Models.WebhookDelivery {
+	var deliveries []Models.WebhookDelivery
+	require.NoError(t, db.Where("subscription_id = ?", subscriptionID).Order("id").Find(&deliveries).Error)
+	return deliveries
+}
+
+func TestSubscriptionValidation(t *testing.T) {
+	db := setupWebhookDB(t)
+	strict := Services.NewWebhookService(db, &Config.WebhookConfig{})
+
+	_, err := strict.CreateSubscription(Services.WebhookSubscriptionInput{
+		Name: "内网", URL: "http://127.0.0.1:8080/hook", EventTypes: []string{Services.EventAlertFired},
+	}, 1)
+	assert.ErrorIs(t, err, Services.ErrWebhookPrivateAddress)
+	_, err = strict.CreateSubscription(Services.WebhookSubscriptionInput{
+		Name: "本机", URL: "http://localhost/hook", EventTypes: []string{Services.EventAlertFired},
+	}, 1)
+	assert.ErrorIs(t, err, Services.ErrWebhookPrivateAddress)
+
+	for _, input := range []Services.WebhookSubscriptionInput{
+		{Name: "地址", URL: "ftp://hooks.example.com", EventTypes: []string{Services.EventAlertFired}},
+		{Name: "事件", URL: "https://hooks.example.com", EventTypes: []string{"order.paid"}},
+		{Name: "通配", URL: "https://hooks.example.com", EventTypes: []string{"order.*"}},
+		{Name: "密钥", URL: "https://hooks.example.com", EventTypes: []string{"*"}, Secret: "short"},
+	} {
+		_, err := strict.CreateSubscription(input, 1)
+		assert.ErrorIs(t, err, Services.ErrInvalidWebhookSubscription, input.Name)
+	}
+
+	subscription, err := strict.CreateSubscription(Services.WebhookSubscriptionInput{
+		Name:       "告警",
+		URL:        "https://hooks.example.com/alerts",
+		EventTypes: []string{"alert.*", "alert.*", Services.EventUserCreated},
+		Filter:     map[string]interface{}{"severity": []string{"critical", "warning"}},
+	}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "alert.*,user.created", subscription.EventTypes)
+	assert.Equal(t, Models.WebhookSubscriptionActive, subscription.Status)
+	assert.Regexp(t, `^whsec_[0-9a-f]{48}$`, subscription.Secret)
+	assert.True(t, subscription.Matches(Services.EventAlertResolved))
+	assert.False(t, subscription.Matches(Services.EventBackupFailed))
+
+	// 更新时不传密钥保留原密钥，轮换后生成新密钥
+	secret := subscription.Secret
+	updated, err := strict.UpdateSubscription(subscription.ID, Services.WebhookSubscriptionInput{
+		Name: "告警", URL: "https://hooks.example.com/v2", EventTypes: []string{"*"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, secret, updated.Secret)
+	assert.Empty(t, updated.Filter)
+	rotated, err := strict.RotateSecret(subscription.ID)
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, rotated.Secret)
+}
+
+func TestHandleEventDeliversSignedRequest(t *testing.T) {
+	db := setupWebhookDB(t)
+	service := newWebhookService(db)
+	target := &receiver{}
+	server := httptest.NewServer(target)
+	defer server.Close()
+
+	critical, err := service.CreateSubscription(Services.WebhookSubscriptionInput{
+		Name: "严重告警", URL: server.URL, EventTypes: []string{"alert.*"},
+		Filter: map[string]interface{}{"severity": []string{"critical", "high"}},
+	}, 1)
+	require.NoError(t, err)
+	users, err := service.CreateSubscription(Services.WebhookSubscriptionInput{
+		Name: "用户", URL: server.URL, EventTypes: []string{Services.EventUserCreated},
+	}, 1)
+	require.NoError(t, err)
+
+	event := domainEvent(Services.EventAlertFired, `{"alert_id":"a1","severity":"critical"}`)
+	require.NoError(t, service.HandleEvent(context.Background(), event))
+	// 事件总线重复投递同一事件时不重复生成投递记录
+	require.NoError(t, service.HandleEvent(context.Background(), event))
+	require.NoError(t, service.HandleEvent(context.Background(), domainEvent(Services.EventAlertResolved, `{"severity":"info"}`)))
+
+	deliveries := deliveriesOf(t, db, critical.ID)
+	require.Len(t, deliveries, 1)
+	assert.Empty(t, deliveriesOf(t, db, users.ID))
+
+	require.NoError(t, service.Deliver(context.Background(), deliveries[0].ID))
+	require.Equal(t, 1, target.count())
+
+	req, body := target.requests[0], target.bodies[0]
+	assert.Equal(t, Services.EventAlertFired, req.Header.Get(Services.WebhookHeaderEvent))
+	assert.Equal(t, deliveries[0].DeliveryID, req.Header.Get(Services.WebhookHeaderDelivery))
+	timestamp := req.Header.Get(Services.WebhookHeaderTimestamp)
+	assert.Equal(t, Services.SignWebhookPayload(critical.Secret, timestamp, body), req.Header.Get(Services.WebhookHeaderSignature))
+
+	var payload Services.WebhookPayload
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, event.ID, payload.EventID)
+	assert.JSONEq(t, `{"alert_id":"a1","severity":"critical"}`, string(payload.Data))
+
+	delivery, err := service.GetDelivery(critical.ID, deliveries[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, Models.WebhookDeliverySucceeded, delivery.Status)
+	assert.Equal(t, http.StatusOK, delivery.ResponseStatus)
+	assert.Contains(t, delivery.ResponseBody, "received")
+	assert.NotNil(t, delivery.DeliveredAt)
+
+	subscription, err := service.GetSubscription(critical.ID)
+	require.NoError(t, err)
+	assert.Equal(t, Models.WebhookDeliverySucceeded, subscription.LastDeliveryStatus)
+}
+
+func TestDeliveryRetriesWithBackoff(t *testing.T) {
+	db := setupWebhookDB(t)
+	service := newWebhookService(db)
+	target := &receiver{statuses: []int{500, 503, 502}}
+	server := httptest.NewServer(target)
+	defer server.Close()
+
+	subscription, err := service.CreateSubscription(Services.WebhookSubscriptionInput{
+		Name: "备份", URL: server.URL, EventTypes: []string{Services.EventBackupFailed},
+	}, 1)
+	require.NoError(t, err)
+	require.NoError(t, service.HandleEvent(context.Background(), domainEvent(Services.EventBackupFailed, `{}`)))
+	id := deliveriesOf(t, db, subscription.ID)[0].ID
+
+	var previous time.Duration
+	for attempt := 1; attempt <= 3; attempt++ {
+		assert.Error(t, service.Deliver(context.Background(), id))
+		delivery, err := service.GetDelivery(subscription.ID, id)
+		require.NoError(t, err)
+		assert.Equal(t, attempt, delivery.Attempts)
+		assert.Contains(t, delivery.LastError, "状态码")
+		if attempt < 3 {
+			require.Equal(t, Models.WebhookDeliveryRetrying, delivery.Status)
+			require.NotNil(t, delivery.NextAttemptAt)
+			wait := time.Until(*delivery.NextAttemptAt)
+			assert.Greater(t, wait, previous, "重试间隔应指数增长")
+			previous = wait
+		} else {
+			assert.Equal(t, Models.WebhookDeliveryFailed, delivery.Status)
+			assert.Equal(t, http.StatusBadGateway, delivery.ResponseStatus)
+		}
+	}
+
+	current, err := service.GetSubscription(subscription.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, current.ConsecutiveFailures)
+	assert.Equal(t, Models.WebhookDeliveryFailed, current.LastDeliveryStatus)
+
+	_, err = service.RetryDelivery(subscription.ID, 999)
+	assert.ErrorIs(t, err, Services.ErrWebhookDeliveryNotFound)
+	retried, err := service.RetryDelivery(subscription.ID, id)
+	require.NoError(t, err)
+	assert.Equal(t, 0, retried.Attempts)
+	_, err = service.RetryDelivery(subscription.ID, id)
+	assert.ErrorIs(t, err, Services.ErrWebhookDeliveryNotRetryable)
+
+	require.NoError(t, service.Deliver(context.Background(), id))
+	current, err = service.GetSubscription(subscription.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, current.ConsecutiveFailures)
+}
+
+func TestPauseAndResume(t *testing.T) {
+	db := setupWebhookDB(t)
+	service := newWebhookService(db)
+	target := &receiver{}
+	server := httptest.NewServer(target)
+	defer server.Close()
+
+	subscription, err := service.CreateSubscription(Services.WebhookSubscriptionInput{
+		Name: "安全", URL: server.URL, EventTypes: []string{Services.EventSecurityEventHigh},
+	}, 1)
+	require.NoError(t, err)
+	paused, err := service.PauseSubscription(subscription.ID)
+	require.NoError(t, err)
+	assert.Equal(t, Models.WebhookSubscriptionPaused, paused.Status)
+	assert.NotNil(t, paused.PausedAt)
+
+	service.Start()
+	defer service.Stop()
+
+	require.NoError(t, service.HandleEvent(context.Background(), domainEvent(Services.EventSecurityEventHigh, `{"level":"critical"}`)))
+	deliveries := deliveriesOf(t, db, subscription.ID)
+	require.Len(t, deliveries, 1)
+
+	// 暂停期间即使被领取也不会发送，也不计入发送次数
+	require.NoError(t, service.Deliver(context.Background(), deliveries[0].ID))
+	delivery, err := service.GetDelivery(subscription.ID, deliveries[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, Models.WebhookDeliveryPending, delivery.Status)
+	assert.Equal(t, 0, delivery.Attempts)
+	assert.Equal(t, 0, target.count())
+
+	resumed, err := service.ResumeSubscription(subscription.ID)
+	require.NoError(t, err)
+	assert.Equal(t, Models.WebhookSubscriptionActive, resumed.Status)
+	require.Eventually(t, func() bool { return target.count() == 1 }, 5*time.Second, 20*time.Millisecond)
+	require.Eventually(t, func() bool {
+		delivery, err := service.GetDelivery(subscription.ID, deliveries[0].ID)
+		return err == nil && delivery.Status == Models.WebhookDeliverySucceeded
+	}, 5*time.Second, 20*time.Millisecond)
+}
+
+func TestEventBusDispatchesToWebhooks(t *testing.T) {
+	db := setupWebhookDB(t)
+	service := newWebhookService(db)
+	target := &receiver{}
+	server := httptest.NewServer(target)
+	defer server.Close()
+
+	bus := Services.NewEventBus(db, Config.EventBusConfig{Enabled: true, BatchSize: 10, MaxAttempts: 3})
+	bus.Subscribe(Services.EventAllTypes, "webhooks", service.HandleEvent)
+
+	subscription, err := service.CreateSubscription(Services.WebhookSubscriptionInput{
+		Name: "用户同步", URL: server.URL, EventTypes: []string{Services.EventUserCreated},
+		Filter: map[string]interface{}{"source": "admin"},
+	}, 1)
+	require.NoError(t, err)
+
+	_, err = bus.Publish(nil, Services.EventUserCreated, "user", "5", map[string]interface{}{"user_id": 5, "source": "admin"})
+	require.NoError(t, err)
+	_, err = bus.Publish(nil, Services.EventUserCreated, "user", "6", map[string]interface{}{"user_id": 6, "source": "register"})
+	require.NoError(t, err)
+	dispatched, err := bus.DispatchPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, dispatched)
+
+	deliveries := deliveriesOf(t, db, subscription.ID)
+	require.Len(t, deliveries, 1)
+	assert.Contains(t, deliveries[0].Payload, `"aggregate_id":"5"`)
+}
+
+func TestWebhookCarriesRequestIDs(t *testing.T) {
+	db := setupWebhookDB(t)
+	service := newWebhookService(db)
+	target := &receiver{}
+	server := httptest.NewServer(target)
+	defer server.Close()
+
+	bus := Services.NewEventBus(db, Config.EventBusConfig{Enabled: true, BatchSize: 10, MaxAttempts: 3})
+	bus.Subscribe(Services.EventAllTypes, "webhooks", service.HandleEvent)
+	subscription, err := service.CreateSubscription(Services.WebhookSubscriptionInput{
+		Name: "用户同步", URL: server.URL, EventTypes: []string{Services.EventUserCreated},
+	}, 1)
+	require.NoError(t, err)
+
+	ctx := Utils.WithRequestIDs(context.Background(), "req-9", "corr-9")
+	_, err = bus.Publish(db.WithContext(ctx), Services.EventUserCreated, "user", "9", map[string]interface{}{"user_id": 9})
+	require.NoError(t, err)
+	_, err = bus.DispatchPending(context.Background())
+	require.NoError(t, err)
+
+	deliveries := deliveriesOf(t, db, subscription.ID)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, "req-9", deliveries[0].RequestID)
+	assert.Equal(t, "corr-9", deliveries[0].CorrelationID)
+
+	// 重试在后台执行，请求头使用投递记录中保存的ID
+	require.NoError(t, service.Deliver(context.Background(), deliveries[0].ID))
+	require.Equal(t, 1, target.count())
+	assert.Equal(t, "req-9", target.requests[0].Header.Get(Utils.RequestIDHeader))
+	assert.Equal(t, "corr-9", target.requests[0].Header.Get(Utils.CorrelationIDHeader))
+}
+
+func TestWebhookController(t *testing.T) {
+	db := setupWebhookDB(t)
+	service := newWebhookService(db)
+	target := &receiver{}
+	server := httptest.NewServer(target)
+	defer server.Close()
+
+	gin.SetMode(gin.TestMode)
+	controller := Controllers.NewWebhookController(service)
+	router := gin.New()
+	router.Use(func(ctx *gin.Context) {
+		ctx.Set("user_id", "1")
+		ctx.Set("user_role", "admin")
+	})
+	router.GET("/api/v1/admin/webhooks/event-types", controller.GetEventTypes)
+	router.GET("/api/v1/admin/webhooks", controller.GetSubscriptions)
+	router.POST("/api/v1/admin/webhooks", controller.CreateSubscription)
+	router.GET("/api/v1/admin/webhooks/:id", controller.GetSubscription)
+	router.POST("/api/v1/admin/webhooks/:id/test", controller.TestSubscription)
+	router.GET("/api/v1/admin/webhooks/:id/deliveries", controller.GetDeliveries)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/webhooks/event-types", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), Services.EventSecurityEventHigh)
+
+	body, _ := json.Marshal(map[string]interface{}{"name": "集成", "url": server.URL, "event_types": []string{"order.created"}})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/webhooks", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	body, _ = json.Marshal(map[string]interface{}{"name": "集成", "url": server.URL, "event_types": []string{"*"}})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/webhooks", bytes.NewReader(body)))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Data struct {
+			Subscription map[string]interface{} `json:"subscription"`
+			Secret       string                 `json:"secret"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.NotEmpty(t, created.Data.Secret)
+	assert.NotContains(t, created.Data.Subscription, "secret")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/webhooks/1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), created.Data.Secret)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/webhooks/99", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/webhooks/1/test", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	deliveries := deliveriesOf(t, db, 1)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, Services.WebhookEventPing, deliveries[0].EventType)
+	require.NoError(t, service.Deliver(context.Background(), deliveries[0].ID))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/webhooks/1/deliveries?filter[status][eq]=succeeded&fields=id,status,response_status", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"response_status":200`)
+	assert.NotContains(t, w.Body.String(), "payload")
+}